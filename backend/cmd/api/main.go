@@ -8,11 +8,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/clients"
 	"github.com/defi-dashboard/backend/internal/config"
+	"github.com/defi-dashboard/backend/internal/mockproviders"
 	"github.com/defi-dashboard/backend/internal/router"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/dbtrace"
 	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/gofiber/fiber/v2"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
@@ -25,8 +28,22 @@ func main() {
 	// Initialize logger
 	logger.Init(cfg.LogLevel)
 
-	// Database connection
-	dbpool, err := pgxpool.New(context.Background(), cfg.DatabaseURL)
+	// Provider calls are answered by fakes from here on
+	if cfg.MockProviders {
+		mockproviders.Install()
+		logger.Warn("Mock provider mode enabled, external providers are faked")
+	}
+
+	// Provider clients share the outbound proxy and egress allowlist
+	if err := clients.ConfigureEgress(cfg.OutboundProxyURL, cfg.GetEgressAllowedHosts()); err != nil {
+		logger.Fatal("Invalid egress configuration", "error", err)
+	}
+
+	// Database connection, with query timings published for /admin/metrics
+	queryTracer := dbtrace.NewTracer(cfg.GetDBSlowQueryThreshold())
+	queryTracer.Publish("db_queries")
+	blockchain.PublishTokenMetadataCache("token_metadata_cache")
+	dbpool, err := cfg.NewTracedPool(context.Background(), queryTracer)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", "error", err)
 	}
@@ -39,6 +56,16 @@ func main() {
 
 	logger.Info("Successfully connected to database")
 
+	// Secrets manager (nil when keys come from the environment only)
+	secretsManager, err := cfg.NewSecretsManager(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to load secrets", "error", err)
+	}
+	if secretsManager != nil {
+		secretsManager.Start(context.Background(), cfg.GetSecretsRefreshInterval())
+		logger.Info("Secrets manager started", "provider", cfg.SecretsProvider)
+	}
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName:               "DeFi Dashboard API",
@@ -46,11 +73,12 @@ func main() {
 		ReadTimeout:           time.Second * 30,
 		WriteTimeout:          time.Second * 30,
 		IdleTimeout:           time.Second * 30,
+		BodyLimit:             cfg.MaxBodySize,
 		DisableStartupMessage: true,
 	})
 
 	// Setup routes
-	router.SetupRoutes(app, dbpool, cfg)
+	router.SetupRoutes(app, dbpool, cfg, secretsManager)
 
 	// Graceful shutdown
 	go func() {
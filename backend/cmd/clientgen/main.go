@@ -0,0 +1,32 @@
+// Command clientgen writes the operation table of the Go SDK from the OpenAPI spec. It
+// runs through go generate in pkg/client.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/defi-dashboard/backend/internal/clientgen"
+)
+
+func main() {
+	specPath := flag.String("spec", "../../../spec/openapi.yaml", "OpenAPI spec to generate from")
+	outPath := flag.String("out", "operations.gen.go", "file to write")
+	flag.Parse()
+
+	source, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to read spec: %v", err)
+	}
+
+	generated, err := clientgen.Generate(source, "spec/"+filepath.Base(*specPath))
+	if err != nil {
+		log.Fatalf("Failed to generate client operations: %v", err)
+	}
+
+	if err := os.WriteFile(*outPath, generated, 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *outPath, err)
+	}
+}
\ No newline at end of file
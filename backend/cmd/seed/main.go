@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/config"
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	reset := flag.Bool("reset", false, "remove existing fixture data before seeding")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load configuration", "error", err)
+	}
+
+	// Initialize logger
+	logger.Init(cfg.LogLevel)
+
+	ctx := context.Background()
+
+	// Database connection
+	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer dbpool.Close()
+
+	if err := dbpool.Ping(ctx); err != nil {
+		logger.Fatal("Failed to ping database", "error", err)
+	}
+
+	// Date the demo activity back from today, so reruns on the same day load identical rows
+	set := fixtures.Default(time.Now().UTC().Truncate(24 * time.Hour))
+
+	if *reset {
+		if err := fixtures.Reset(ctx, dbpool, set); err != nil {
+			logger.Fatal("Failed to reset fixtures", "error", err)
+		}
+		logger.Info("Removed existing fixture data")
+	}
+
+	if err := fixtures.Load(ctx, dbpool, set); err != nil {
+		logger.Fatal("Failed to seed database", "error", err)
+	}
+
+	logger.Info("Database seeded",
+		"users", len(set.Users),
+		"wallets", len(set.Wallets),
+		"transactions", len(set.Transactions),
+		"yieldPositions", len(set.YieldPositions),
+		"alerts", len(set.Alerts))
+	for _, user := range set.Users {
+		logger.Info("Demo user", "address", user.Address, "admin", user.IsAdmin)
+	}
+}
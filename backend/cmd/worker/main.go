@@ -2,18 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/clients"
 	"github.com/defi-dashboard/backend/internal/config"
+	"github.com/defi-dashboard/backend/internal/events"
 	"github.com/defi-dashboard/backend/internal/jobs"
+	"github.com/defi-dashboard/backend/internal/mockproviders"
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/internal/workerrpc"
+	"github.com/defi-dashboard/backend/pkg/analytics"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/defi-dashboard/backend/pkg/dbtrace"
 	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/feeds"
 	"github.com/defi-dashboard/backend/pkg/logger"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/defi-dashboard/backend/pkg/pnl"
 	"github.com/robfig/cron/v3"
 )
 
@@ -28,12 +41,35 @@ func main() {
 	logger.Init(cfg.LogLevel)
 	logger.Info("Starting DeFi Dashboard Worker", "version", "1.0.0")
 
+	// `worker run <job>` runs a single job and exits instead of starting the scheduler
+	var once *jobRun
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		if once, err = parseJobRun(os.Args[2:], os.Stderr); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				os.Exit(0)
+			}
+			logger.Error("Invalid job run", "error", err)
+			os.Exit(2)
+		}
+	}
+
+	// Provider calls are answered by fakes from here on
+	if cfg.MockProviders {
+		mockproviders.Install()
+		logger.Warn("Mock provider mode enabled, external providers are faked")
+	}
+
+	// Provider clients share the outbound proxy and egress allowlist
+	if err := clients.ConfigureEgress(cfg.OutboundProxyURL, cfg.GetEgressAllowedHosts()); err != nil {
+		logger.Fatal("Invalid egress configuration", "error", err)
+	}
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Database connection
-	dbpool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	// Database connection; slow queries are logged
+	dbpool, err := cfg.NewTracedPool(ctx, dbtrace.NewTracer(cfg.GetDBSlowQueryThreshold()))
 	if err != nil {
 		logger.Fatal("Failed to connect to database", "error", err)
 	}
@@ -45,49 +81,366 @@ func main() {
 	}
 	logger.Info("Successfully connected to database")
 
+	// Log level and debug sampling set through the admin API; SIGHUP reloads them at once
+	logSettingsService := services.NewLogSettingsService(repos.NewFeatureFlagRepository(dbpool), cfg.LogLevel, cfg.LogDebugSampleRate)
+	if err := logSettingsService.Load(ctx); err != nil {
+		logger.Error("Failed to load log settings", "error", err)
+	}
+	go logSettingsService.Watch(ctx, time.Duration(cfg.LogSettingsReloadInterval)*time.Second)
+
+	// Provider calls are logged for support lookups by request ID
+	providerCallRepo := repos.NewProviderCallRepository(dbpool)
+	if cfg.ProviderCallLog {
+		providerCallLog := services.NewProviderCallLog(providerCallRepo, models.ProviderCallSourceWorker, cfg.ProviderCallLogAll)
+		correlation.Observe(providerCallLog.Observe)
+		go providerCallLog.Run(ctx)
+	}
+
+	// Secrets manager (nil when keys come from the environment only)
+	secretsManager, err := cfg.NewSecretsManager(ctx)
+	if err != nil {
+		logger.Fatal("Failed to load secrets", "error", err)
+	}
+
+	// Per-chain finality overrides
+	finalityThresholds, err := cfg.GetFinalityThresholds()
+	if err != nil {
+		logger.Fatal("Invalid finality thresholds", "error", err)
+	}
+	blockchain.SetFinalityThresholds(finalityThresholds)
+
+	// JSON-RPC batch sizes
+	batchSizes, err := cfg.GetRPCBatchSizes()
+	if err != nil {
+		logger.Fatal("Invalid RPC batch sizes", "error", err)
+	}
+	blockchain.SetRPCBatchSizes(cfg.RPCBatchSize, batchSizes)
+
 	// Initialize external API clients
 	coinGeckoClient := external.NewCoinGeckoClient(cfg.CoinGeckoAPIKey)
 	defiLlamaClient := external.NewDefiLlamaClient()
+	gmxClient := external.NewGMXClient()
+	hyperliquidClient := external.NewHyperliquidClient()
+	esploraClient := external.NewEsploraClient(cfg.BitcoinAPIURL)
+	blockchainService := blockchain.NewBlockchainService(cfg.AlchemyAPIKey, cfg.CoinGeckoAPIKey)
+
+	// Rotate keys of the long-lived clients without a restart
+	if secretsManager != nil {
+		secretsManager.OnChange(config.SecretCoinGeckoAPIKey, coinGeckoClient.SetAPIKey)
+		secretsManager.OnChange(config.SecretCoinGeckoAPIKey, blockchainService.SetCoinGeckoAPIKey)
+		secretsManager.OnChange(config.SecretAlchemyAPIKey, blockchainService.SetAlchemyAPIKey)
+		secretsManager.Start(ctx, cfg.GetSecretsRefreshInterval())
+	}
+
+	// Exchange API keys and alert webhook URLs are stored encrypted; without
+	// ENCRYPTION_KEY they can't be read
+	encryptor, err := cfg.GetEncryptor()
+	if err != nil {
+		logger.Error("Failed to initialize encryptor, exchange account sync disabled", "error", err)
+		encryptor = nil
+	}
 
 	// Initialize repositories
-	alertRepo := repos.NewAlertRepository(dbpool)
+	alertRepo := repos.NewAlertRepository(dbpool, encryptor)
 	userRepo := repos.NewUserRepository(dbpool)
+	walletRepo := repos.NewWalletRepository(dbpool)
+	tokenRepo := repos.NewTokenRepository(dbpool)
+	notificationRepo := repos.NewNotificationRepository(dbpool)
+	transactionRepo := repos.NewTransactionRepository(dbpool)
+	tokenMetadataRepo := repos.NewTokenMetadataRepository(dbpool)
+	protocolRepo := repos.NewProtocolRepository(dbpool)
+	protocolTVLRepo := repos.NewProtocolTVLRepository(dbpool)
+	liquidationRiskRepo := repos.NewLiquidationRiskRepository(dbpool)
+	derivativePositionRepo := repos.NewDerivativePositionRepository(dbpool)
+	bitcoinRepo := repos.NewBitcoinRepository(dbpool)
+	exchangeRepo := repos.NewExchangeRepository(dbpool)
+
+	// Token metadata fetched by any blockchain client is cached in the tokens table
+	blockchain.SetTokenMetadataStore(tokenRepo)
+	blockchain.SetTestnetMode(cfg.TestnetMode)
 
-	// Initialize services
-	alertService := services.NewAlertService(alertRepo, userRepo)
+	// Custom EVM chains registered through the API are read through their own RPC endpoints
+	chainService := services.NewChainService(repos.NewCustomChainRepository(dbpool), repos.NewFeatureFlagRepository(dbpool))
+	if err := chainService.Load(ctx); err != nil {
+		logger.Error("Failed to load custom chains", "error", err)
+	}
+
+	// Initialize services. Evaluators for custom alert types are registered with
+	// services.RegisterAlertEvaluator here, before the alert evaluator job first runs.
+	eventPublisher := events.NewPGPublisher(dbpool)
+	emailSender, err := cfg.NewEmailSender()
+	if err != nil {
+		logger.Fatal("Failed to initialize email", "error", err)
+	}
+	emailService := services.NewEmailService(emailSender, repos.NewEmailRepository(dbpool))
+	// Webhooks are only posted to public addresses, and to URLs their owner verified
+	webhookPolicy := cfg.GetWebhookPolicy()
+	webhookVerificationService := services.NewWebhookVerificationService(repos.NewWebhookVerificationRepository(dbpool), webhookPolicy)
+	notificationChannels := services.NewNotificationChannelRegistry()
+	notificationHTTPClient := webhookPolicy.Client(10 * time.Second)
+	for _, channel := range []services.NotificationChannel{
+		services.NewEmailChannel(emailService),
+		services.NewVerifiedWebhookChannel(notificationHTTPClient, webhookVerificationService),
+		services.NewDiscordChannel(notificationHTTPClient),
+		services.NewInAppChannel(eventPublisher),
+	} {
+		if err := notificationChannels.Register(channel); err != nil {
+			logger.Fatal("Failed to register notification channel", "error", err)
+		}
+	}
+	if cfg.TelegramBotToken != "" {
+		if err := notificationChannels.Register(services.NewTelegramChannel(notificationHTTPClient, cfg.TelegramBotToken)); err != nil {
+			logger.Fatal("Failed to register notification channel", "error", err)
+		}
+	}
+	notificationTemplates := services.NewNotificationTemplateService(tokenRepo, walletRepo)
+	notificationDispatcher := services.NewNotificationDispatcherWithChannels(notificationRepo, userRepo, notificationChannels, notificationTemplates)
+	escalationService := services.NewEscalationService(repos.NewEscalationRepository(dbpool), repos.NewTeamRepository(dbpool), alertRepo, userRepo, notificationChannels, notificationTemplates)
+	notificationOutbox := services.NewNotificationOutboxWithEscalations(repos.NewNotificationOutboxRepository(dbpool, encryptor), alertRepo, notificationDispatcher, escalationService)
+	alertService := services.NewAlertServiceWithOutbox(alertRepo, userRepo, notificationOutbox)
+	bridgeService := services.NewBridgeService(cfg.GetLiFiClientConfig(), cfg.GetSocketClientConfig())
+	pnlService := pnl.NewServiceWithBridgeStatus(pnl.NewRepository(dbpool), walletRepo, tokenRepo, bridgeService)
+
+	exchangeService := services.NewExchangeService(exchangeRepo, encryptor)
+
+	store, err := cfg.NewStorage()
+	if err != nil {
+		logger.Fatal("Failed to initialize storage", "error", err)
+	}
+	uploadService := services.NewUploadService(repos.NewUploadRepository(dbpool), store, cfg.GetUploadRetention(), cfg.GetSignedURLExpiry())
+	reportService := services.NewReportService(
+		repos.NewReportRepository(dbpool), walletRepo, userRepo, transactionRepo,
+		services.NewPortfolioService(walletRepo, tokenRepo, tokenMetadataRepo, derivativePositionRepo, esploraClient),
+		services.NewBitcoinService(bitcoinRepo, esploraClient), exchangeService, pnlService,
+		uploadService, emailService,
+	)
 
 	// Initialize job handlers
 	priceJob := jobs.NewPriceRefreshJob(dbpool, coinGeckoClient, defiLlamaClient)
-	alertJob := jobs.NewAlertEvaluatorJob(dbpool, alertService, alertRepo)
+	budgetService := services.NewBudgetService(repos.NewBudgetRepository(dbpool), walletRepo, transactionRepo, pnlService)
+	alertJob := jobs.NewAlertEvaluatorJob(dbpool, alertService, alertRepo, coinGeckoClient, blockchainService, budgetService)
+	gasFeeJob := jobs.NewGasFeeBackfillJob(dbpool, blockchainService)
+	nftSyncJob := jobs.NewNFTTransferSyncJob(dbpool, blockchainService, pnlService)
+	notificationQueueJob := jobs.NewNotificationQueueJob(notificationDispatcher)
+	notificationOutboxJob := jobs.NewNotificationOutboxJob(notificationOutbox)
+	escalationJob := jobs.NewEscalationJob(escalationService)
+	reorgJob := jobs.NewReorgDetectionJob(dbpool, blockchainService, pnlService, repos.NewWalletBackfillRepository(dbpool), repos.NewBalanceRepository(dbpool), eventPublisher)
+	confirmationJob := jobs.NewConfirmationTrackerJob(transactionRepo, blockchainService, eventPublisher)
+	tokenMetadataJob := jobs.NewTokenMetadataJob(tokenMetadataRepo, coinGeckoClient)
+	tokenListSyncJob := jobs.NewTokenListSyncJob(repos.NewTokenListRepository(dbpool), external.NewTokenListClient())
+	protocolTVLJob := jobs.NewProtocolTVLSyncJob(protocolRepo, protocolTVLRepo, defiLlamaClient)
+	liquidationMonitorJob := jobs.NewLiquidationMonitorJob(alertRepo, liquidationRiskRepo, blockchainService, notificationOutbox, eventPublisher)
+	derivativeSyncJob := jobs.NewDerivativePositionSyncJob(derivativePositionRepo, blockchainService, gmxClient, hyperliquidClient)
+	bitcoinSyncJob := jobs.NewBitcoinSyncJob(bitcoinRepo, esploraClient, coinGeckoClient)
+	exchangeSyncJob := jobs.NewExchangeSyncJob(exchangeRepo, exchangeService, coinGeckoClient)
+	monthlyStatementJob := jobs.NewMonthlyStatementJob(reportService)
+	uploadRetentionJob := jobs.NewUploadRetentionJob(uploadService)
+	internalTransferJob := jobs.NewInternalTransferMatchJob(pnlService)
+	walletValuationJob := jobs.NewWalletValuationJob(repos.NewWalletValuationRepository(dbpool))
+	walletSyncJob := jobs.NewWalletSyncJob(walletRepo, nftSyncJob, derivativeSyncJob)
+	walletBackfillRepo := repos.NewWalletBackfillRepository(dbpool)
+	onboardingService := services.NewOnboardingService(repos.NewOnboardingRepository(dbpool), eventPublisher)
+	walletBackfillJob := jobs.NewWalletBackfillJob(walletBackfillRepo, blockchainService, onboardingService)
+	// Newly seen wallets and triggered alerts are counted in usage analytics, and as
+	// conversions in the experiments their users were exposed to
+	analyticsSink, err := analytics.NewSink(cfg.GetAnalyticsConfig())
+	if err != nil {
+		logger.Error("Invalid analytics settings, usage analytics disabled", "error", err)
+	}
+	analyticsService := services.NewAnalyticsService(repos.NewAnalyticsRepository(dbpool), analyticsSink, cfg.AnalyticsSalt)
+	analyticsService.SetExperiments(services.NewExperimentService(repos.NewExperimentRepository(dbpool), repos.NewFeatureFlagRepository(dbpool), analyticsService))
+	go analyticsService.Run(ctx)
+	walletBackfillJob.SetAnalytics(analyticsService)
+	alertJob.SetAnalytics(analyticsService)
+	alertJob.SetMaxDataAge(cfg.GetAlertMaxDataAge())
+	// Per-type evaluation timings, served with the worker RPC at GET /metrics
+	alertJob.PublishMetrics("alert_evaluation")
+	walletAccountTypeJob := jobs.NewWalletAccountTypeJob(walletRepo, blockchainService)
+	balanceRefreshJob := jobs.NewBalanceRefreshJob(repos.NewBalanceRefreshRepository(dbpool), repos.NewBalanceRepository(dbpool), blockchainService, eventPublisher)
+	walletRemovalJob := jobs.NewWalletRemovalJob(repos.NewWalletRemovalRepository(dbpool))
+	alertTargetJob := jobs.NewAlertTargetJob(repos.NewAlertTargetRepository(dbpool), notificationOutbox, cfg.GetAlertTargetGoneAfter())
+	etherscanClient := external.NewEtherscanClient(cfg.EtherscanAPIKey)
+	tokenHolderJob := jobs.NewTokenHolderJob(repos.NewTokenHolderRepository(dbpool), etherscanClient)
+	contractMetadataJob := jobs.NewContractMetadataJob(repos.NewContractRepository(dbpool), etherscanClient)
+	yieldPoolMigrationJob := jobs.NewYieldPoolMigrationJob(services.NewYieldPoolMigrationService(
+		repos.NewYieldPoolRepository(dbpool), repos.NewYieldPoolMigrationRepository(dbpool), emailService, cfg.PublicURL))
+	feedRepo := repos.NewFeedRepository(dbpool)
+	feedIngestJob := jobs.NewFeedIngestJob(feedRepo, feeds.NewFetcher())
+	broadcastEmailJob := jobs.NewBroadcastEmailJob(services.NewBroadcastService(repos.NewBroadcastRepository(dbpool),
+		repos.NewSystemBannerRepository(dbpool), protocolRepo, feedRepo, emailService))
+	webhookReportJob := jobs.NewWebhookReportJob(services.NewWebhookReportService(repos.NewWebhookReportRepository(dbpool, encryptor),
+		repos.NewYieldPositionRepository(dbpool), walletRepo, repos.NewWalletValuationRepository(dbpool), userRepo,
+		webhookVerificationService, webhookPolicy, emailService))
+	partitionJob := jobs.NewPartitionMaintenanceJob(repos.NewPartitionRepository(dbpool), cfg.GetAlertHistoryRetention())
+	providerCallRetentionJob := jobs.NewProviderCallRetentionJob(providerCallRepo)
+	billingService := services.NewBillingService(repos.NewBillingRepository(dbpool),
+		repos.NewPlanRepository(dbpool), repos.NewFeatureFlagRepository(dbpool), userRepo, nil, cfg.GetBillingConfig())
+	billingService.SetUnitOfWork(repos.NewUnitOfWork(dbpool))
+	billingGraceJob := jobs.NewBillingGraceJob(billingService)
+	complianceJob := jobs.NewComplianceScreeningJob(services.NewComplianceService(repos.NewComplianceRepository(dbpool), repos.NewFeatureFlagRepository(dbpool)))
+	alchemyWebhooks, err := cfg.GetAlchemyWebhooks()
+	if err != nil {
+		logger.Fatal("Invalid Alchemy webhooks", "error", err)
+	}
+	var alchemyNotifyClient *blockchain.AlchemyNotifyClient
+	if cfg.AlchemyNotifyToken != "" {
+		alchemyNotifyClient = blockchain.NewAlchemyNotifyClient(cfg.AlchemyNotifyToken)
+	}
+	alchemyWebhookJob := jobs.NewAlchemyWebhookJob(repos.NewAlchemyWebhookRepository(dbpool), walletRepo, walletBackfillRepo,
+		blockchainService, alertJob, alchemyNotifyClient, alchemyWebhooks)
 
-	// Create cron scheduler with seconds support
-	c := cron.New(cron.WithSeconds())
+	// Advisory-lock based job locking so replicas never run the same job concurrently
+	jobLocker := jobs.NewJobLocker(dbpool)
+	// Each run is recorded for the status endpoint
+	jobLocker.RecordRuns(repos.NewSystemStatusRepository(dbpool))
 
-	// Schedule jobs
-	// Price refresh every 10 minutes
-	_, err = c.AddFunc("0 */10 * * * *", func() {
-		runJob(ctx, "price-refresh", priceJob.Run)
-	})
+	// Jobs the worker schedules, by their name in the worker job graph
+	scheduled := map[string]func(context.Context) error{
+		"price-refresh":            priceJob.Run,
+		"wallet-valuation":         walletValuationJob.Run,
+		"alert-evaluator":          alertJob.Run,
+		"gas-fee-backfill":         gasFeeJob.Run,
+		"nft-transfer-sync":        nftSyncJob.Run,
+		"notification-queue":       notificationQueueJob.Run,
+		"notification-outbox":      notificationOutboxJob.Run,
+		"alert-escalation":         escalationJob.Run,
+		"reorg-detection":          reorgJob.Run,
+		"confirmation-tracker":     confirmationJob.Run,
+		"token-metadata":           tokenMetadataJob.Run,
+		"protocol-tvl-sync":        protocolTVLJob.Run,
+		"liquidation-monitor":      liquidationMonitorJob.Run,
+		"derivative-position-sync": derivativeSyncJob.Run,
+		"bitcoin-sync":             bitcoinSyncJob.Run,
+		"monthly-statements":       monthlyStatementJob.Run,
+		"upload-retention":         uploadRetentionJob.Run,
+		"internal-transfer-match":  internalTransferJob.Run,
+		"wallet-backfill":          walletBackfillJob.Run,
+		"balance-refresh":          balanceRefreshJob.Run,
+		"wallet-account-type":      walletAccountTypeJob.Run,
+		"wallet-removal":           walletRemovalJob.Run,
+		"alert-target-reconcile":   alertTargetJob.Run,
+		"pool-migration-notices":   yieldPoolMigrationJob.Run,
+		"feed-ingest":              feedIngestJob.Run,
+		"partition-maintenance":    partitionJob.Run,
+		"provider-call-retention":  providerCallRetentionJob.Run,
+		"billing-grace":            billingGraceJob.Run,
+		"compliance-screening":     complianceJob.Run,
+		"broadcast-emails":         broadcastEmailJob.Run,
+		"webhook-reports":          webhookReportJob.Run,
+		"token-list-sync":          tokenListSyncJob.Run,
+	}
+	if encryptor != nil {
+		scheduled["exchange-sync"] = exchangeSyncJob.Run
+	}
+	if cfg.EtherscanAPIKey != "" {
+		scheduled["token-holders"] = tokenHolderJob.Run
+		scheduled["contract-metadata"] = contractMetadataJob.Run
+	}
+	if len(alchemyWebhooks) > 0 {
+		scheduled["alchemy-webhook-events"] = alchemyWebhookJob.Run
+		scheduled["alchemy-webhook-subscriptions"] = alchemyWebhookJob.SyncSubscriptions
+	}
+
+	if once != nil {
+		runnable := make(map[string]runnableJob, len(scheduled)+1)
+		for name, run := range scheduled {
+			runnable[name] = runnableJob{run: run}
+		}
+		runnable["alert-evaluator"] = runnableJob{run: alertJob.Run, flags: []string{"alert"}, target: func(ctx context.Context, r *jobRun) error {
+			triggered, err := alertJob.EvaluateAlert(ctx, *r.alertID)
+			if err != nil {
+				return err
+			}
+			logger.Info("Alert evaluated", "alertId", *r.alertID, "triggered", triggered)
+			return nil
+		}}
+		runnable["reorg-detection"] = runnableJob{run: reorgJob.Run, flags: []string{"chain"}, target: func(ctx context.Context, r *jobRun) error {
+			return reorgJob.RunChain(ctx, r.chainID)
+		}}
+		runnable["wallet-backfill"] = runnableJob{run: walletBackfillJob.Run, flags: []string{"wallet"}, target: func(ctx context.Context, r *jobRun) error {
+			return walletBackfillJob.BackfillWallet(ctx, *r.walletID)
+		}}
+		// Wallet syncs are otherwise only requested through the API
+		runnable["wallet-sync"] = runnableJob{flags: []string{"wallet"}, target: func(ctx context.Context, r *jobRun) error {
+			return walletSyncJob.Sync(ctx, *r.walletID, func(step string) {
+				logger.Info("Syncing wallet", "walletId", *r.walletID, "step", step)
+			})
+		}}
+
+		code := runOnce(ctx, jobLocker, runnable, once)
+		cancel()
+		dbpool.Close()
+		os.Exit(code)
+	}
+
+	// Jobs run on the schedules of the worker job graph; a job due together with jobs
+	// it depends on runs after them, and is skipped when one of them fails
+	jobGraph, err := services.NewWorkerJobGraph()
 	if err != nil {
-		logger.Fatal("Failed to schedule price refresh job", "error", err)
+		logger.Fatal("Invalid worker job graph", "error", err)
+	}
+	scheduler := jobs.NewScheduler(jobGraph, func(ctx context.Context, name string, run func(context.Context) error) (bool, error) {
+		return runJob(ctx, jobLocker, name, run)
+	})
+	for name, run := range scheduled {
+		if err := scheduler.Bind(name, run); err != nil {
+			logger.Fatal("Failed to schedule job", "job", name, "error", err)
+		}
+	}
+
+	// Create cron scheduler with seconds support
+	c := cron.New(cron.WithSeconds())
+	if err := scheduler.Start(ctx, c); err != nil {
+		logger.Fatal("Failed to schedule jobs", "error", err)
 	}
 
-	// Alert evaluation every 5 minutes
-	_, err = c.AddFunc("0 */5 * * * *", func() {
-		runJob(ctx, "alert-evaluator", alertJob.Run)
+	// Reload custom chains every minute on every replica, so chains registered through the
+	// API are picked up without a restart
+	_, err = c.AddFunc("30 * * * * *", func() {
+		if err := chainService.Load(ctx); err != nil {
+			logger.Error("Failed to reload custom chains", "error", err)
+		}
 	})
 	if err != nil {
-		logger.Fatal("Failed to schedule alert evaluator job", "error", err)
+		logger.Fatal("Failed to schedule custom chain reload", "error", err)
 	}
 
 	// Start cron scheduler
 	c.Start()
 	logger.Info("Worker scheduled jobs started")
 
+	// Serve on-demand tasks requested by the API
+	var rpcServer *workerrpc.Server
+	var rpcHTTPServer *http.Server
+	if cfg.WorkerRPCAddr != "" {
+		rpcServer = workerrpc.NewServer(ctx, cfg.WorkerRPCToken, eventPublisher)
+		rpcServer.Register(workerrpc.TaskSyncWallet, func(ctx context.Context, req workerrpc.TaskRequest, progress func(string)) (map[string]interface{}, error) {
+			return nil, walletSyncJob.Sync(ctx, req.TargetID, progress)
+		})
+		rpcServer.Register(workerrpc.TaskEvaluateAlert, func(ctx context.Context, req workerrpc.TaskRequest, progress func(string)) (map[string]interface{}, error) {
+			triggered, err := alertJob.EvaluateAlert(ctx, req.TargetID)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"triggered": triggered}, nil
+		})
+
+		rpcHTTPServer = &http.Server{
+			Addr:              cfg.WorkerRPCAddr,
+			Handler:           rpcServer.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			if err := rpcHTTPServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Worker RPC server failed", "error", err)
+			}
+		}()
+		logger.Info("Worker RPC server started", "addr", cfg.WorkerRPCAddr)
+	}
+
 	// Run initial jobs on startup
 	logger.Info("Running initial jobs on startup")
-	runJob(ctx, "price-refresh-startup", priceJob.Run)
-	runJob(ctx, "alert-evaluator-startup", alertJob.Run)
+	scheduler.RunNow(ctx, "price-refresh", "alert-evaluator")
 
 	// Wait for shutdown signal
 	sigChan := make(chan os.Signal, 1)
@@ -96,6 +449,15 @@ func main() {
 	<-sigChan
 	logger.Info("Shutdown signal received, stopping worker...")
 
+	// Stop taking on-demand tasks
+	if rpcHTTPServer != nil {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := rpcHTTPServer.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("Failed to stop worker RPC server", "error", err)
+		}
+		shutdownCancel()
+	}
+
 	// Stop cron scheduler
 	cronCtx := c.Stop()
 	
@@ -106,6 +468,9 @@ func main() {
 	done := make(chan struct{})
 	go func() {
 		<-cronCtx.Done()
+		if rpcServer != nil {
+			rpcServer.Wait()
+		}
 		close(done)
 	}()
 
@@ -119,9 +484,10 @@ func main() {
 	logger.Info("Worker shutdown complete")
 }
 
-// runJob executes a job with proper error handling and logging
-func runJob(ctx context.Context, jobName string, jobFunc func(context.Context) error) {
-	logger.Info("Starting job", "job", jobName)
+// runJob executes a job with proper error handling and logging. The job is
+// skipped, reporting false, if another worker replica currently holds its lock
+// or already ran its tick.
+func runJob(ctx context.Context, locker *jobs.JobLocker, jobName string, jobFunc func(context.Context) error) (bool, error) {
 	start := time.Now()
 
 	// Create a timeout context for the job
@@ -129,15 +495,30 @@ func runJob(ctx context.Context, jobName string, jobFunc func(context.Context) e
 	defer cancel()
 
 	// Run the job
-	if err := jobFunc(jobCtx); err != nil {
+	ran, err := locker.RunExclusive(jobCtx, jobName, func(ctx context.Context) error {
+		logger.Info("Starting job", "job", jobName)
+		return jobFunc(ctx)
+	})
+	if !ran && err == nil {
+		logger.Info("Job skipped, running or already run on another worker", "job", jobName)
+		return false, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Warn("Job interrupted by shutdown, will resume from checkpoint",
+				"job", jobName,
+				"duration", time.Since(start))
+			return ran, err
+		}
 		logger.Error("Job failed", 
 			"job", jobName, 
 			"error", err, 
 			"duration", time.Since(start))
-		return
+		return ran, err
 	}
 
 	logger.Info("Job completed successfully", 
 		"job", jobName, 
 		"duration", time.Since(start))
+	return true, nil
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/jobs"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const runUsage = `Usage: worker run <job> [flags]
+
+Runs one job once and exits instead of starting the scheduler, e.g. from a Kubernetes
+Job. The job takes the same lock as its scheduled runs, so it never overlaps them.
+
+Flags:
+`
+
+// defaultRunTimeout bounds a single-shot run; scheduled runs get five minutes, but a
+// manual backfill of a long history can take much longer
+const defaultRunTimeout = time.Hour
+
+// jobRun is a single run of one job requested on the command line. Targets narrow the
+// run to one wallet, alert or chain for jobs that support it.
+type jobRun struct {
+	job      string
+	walletID *uuid.UUID
+	alertID  *uuid.UUID
+	chainID  int
+	timeout  time.Duration
+}
+
+// runnableJob is a job the worker can run once. run runs it in full, as the scheduler
+// does, and is nil for jobs that only run for a target; target runs it for the targets
+// named in flags.
+type runnableJob struct {
+	run    func(ctx context.Context) error
+	target func(ctx context.Context, r *jobRun) error
+	flags  []string
+}
+
+// parseJobRun parses the arguments following `worker run`
+func parseJobRun(args []string, output io.Writer) (*jobRun, error) {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(output, runUsage)
+		fs.PrintDefaults()
+	}
+	wallet := fs.String("wallet", "", "only run for the wallet with this ID")
+	alert := fs.String("alert", "", "only run for the alert with this ID")
+	chain := fs.Int("chain", 0, "only run for this chain ID")
+	timeout := fs.Duration("timeout", defaultRunTimeout, "give up after this long")
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fs.Usage()
+		return nil, errors.New("a job name is required")
+	}
+	r := &jobRun{job: args[0]}
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *wallet != "" {
+		id, err := uuid.Parse(*wallet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --wallet: %w", err)
+		}
+		r.walletID = &id
+	}
+	if *alert != "" {
+		id, err := uuid.Parse(*alert)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --alert: %w", err)
+		}
+		r.alertID = &id
+	}
+	if *chain < 0 {
+		return nil, errors.New("invalid --chain")
+	}
+	r.chainID = *chain
+	if *timeout <= 0 {
+		return nil, errors.New("--timeout must be positive")
+	}
+	r.timeout = *timeout
+	return r, nil
+}
+
+// targetFlags lists the targeting flags the run was given
+func (r *jobRun) targetFlags() []string {
+	var flags []string
+	if r.walletID != nil {
+		flags = append(flags, "wallet")
+	}
+	if r.alertID != nil {
+		flags = append(flags, "alert")
+	}
+	if r.chainID != 0 {
+		flags = append(flags, "chain")
+	}
+	return flags
+}
+
+// resolve returns the function running the job as requested
+func (r *jobRun) resolve(available map[string]runnableJob) (func(context.Context) error, error) {
+	job, ok := available[r.job]
+	if !ok {
+		names := make([]string, 0, len(available))
+		for name := range available {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown job %q, expected one of: %s", r.job, strings.Join(names, ", "))
+	}
+
+	given := r.targetFlags()
+	for _, name := range given {
+		if !contains(job.flags, name) {
+			return nil, fmt.Errorf("job %s doesn't take --%s", r.job, name)
+		}
+	}
+	if len(given) > 0 {
+		return func(ctx context.Context) error { return job.target(ctx, r) }, nil
+	}
+	if job.run == nil {
+		return nil, fmt.Errorf("job %s needs --%s", r.job, strings.Join(job.flags, " or --"))
+	}
+	return job.run, nil
+}
+
+// runOnce runs the requested job under its lock and returns the process exit code
+func runOnce(ctx context.Context, locker *jobs.JobLocker, available map[string]runnableJob, r *jobRun) int {
+	fn, err := r.resolve(available)
+	if err != nil {
+		logger.Error("Invalid job run", "error", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	logger.Info("Running job once", "job", r.job, "targets", r.targetFlags())
+	ran, err := locker.RunExclusive(ctx, r.job, fn)
+	if !ran && err == nil {
+		logger.Error("Job is already running on another worker", "job", r.job)
+		return 1
+	}
+	if err != nil {
+		logger.Error("Job failed", "job", r.job, "error", err, "duration", time.Since(start))
+		return 1
+	}
+
+	logger.Info("Job completed successfully", "job", r.job, "duration", time.Since(start))
+	return 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
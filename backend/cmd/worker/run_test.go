@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJobRun(t *testing.T) {
+	walletID := uuid.New()
+	r, err := parseJobRun([]string{"wallet-backfill", "--wallet", walletID.String(), "--timeout", "2h"}, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, "wallet-backfill", r.job)
+	require.NotNil(t, r.walletID)
+	assert.Equal(t, walletID, *r.walletID)
+	assert.Equal(t, 2*time.Hour, r.timeout)
+	assert.Equal(t, []string{"wallet"}, r.targetFlags())
+
+	r, err = parseJobRun([]string{"price-refresh"}, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, defaultRunTimeout, r.timeout)
+	assert.Empty(t, r.targetFlags())
+
+	_, err = parseJobRun([]string{"--help"}, io.Discard)
+	assert.Error(t, err, "the job name comes first")
+	_, err = parseJobRun([]string{"price-refresh", "--help"}, io.Discard)
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+
+	for _, args := range [][]string{
+		nil,
+		{"wallet-backfill", "--wallet", "not-a-uuid"},
+		{"alert-evaluator", "--alert", "42"},
+		{"reorg-detection", "--chain", "-1"},
+		{"price-refresh", "--timeout", "0s"},
+		{"price-refresh", "extra"},
+	} {
+		_, err := parseJobRun(args, io.Discard)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestJobRunResolve(t *testing.T) {
+	var ran []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	available := map[string]runnableJob{
+		"price-refresh": {run: record("price-refresh")},
+		"reorg-detection": {run: record("reorg-detection"), flags: []string{"chain"}, target: func(ctx context.Context, r *jobRun) error {
+			return record("reorg-detection chain")(ctx)
+		}},
+		"wallet-sync": {flags: []string{"wallet"}, target: func(ctx context.Context, r *jobRun) error {
+			return record("wallet-sync " + r.walletID.String())(ctx)
+		}},
+	}
+	walletID := uuid.New()
+
+	// Without targets a job runs in full, with them only for the targets
+	for _, r := range []*jobRun{
+		{job: "price-refresh"},
+		{job: "reorg-detection"},
+		{job: "reorg-detection", chainID: 1},
+		{job: "wallet-sync", walletID: &walletID},
+	} {
+		fn, err := r.resolve(available)
+		require.NoError(t, err, r.job)
+		require.NoError(t, fn(context.Background()))
+	}
+	assert.Equal(t, []string{"price-refresh", "reorg-detection", "reorg-detection chain", "wallet-sync " + walletID.String()}, ran)
+
+	_, err := (&jobRun{job: "nope"}).resolve(available)
+	assert.EqualError(t, err, `unknown job "nope", expected one of: price-refresh, reorg-detection, wallet-sync`)
+	_, err = (&jobRun{job: "price-refresh", walletID: &walletID}).resolve(available)
+	assert.EqualError(t, err, "job price-refresh doesn't take --wallet")
+	_, err = (&jobRun{job: "wallet-sync"}).resolve(available)
+	assert.EqualError(t, err, "job wallet-sync needs --wallet")
+}
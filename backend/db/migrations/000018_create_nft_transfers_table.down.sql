@@ -0,0 +1,5 @@
+-- Drop nft_transfers table
+DROP TABLE IF EXISTS nft_transfers;
+
+-- Drop enum type
+DROP TYPE IF EXISTS nft_standard;
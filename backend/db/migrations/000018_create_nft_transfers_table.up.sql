@@ -0,0 +1,27 @@
+-- Create NFT standard enum
+CREATE TYPE nft_standard AS ENUM ('erc721', 'erc1155');
+
+-- Create nft_transfers table for NFT history and cost-basis lots
+CREATE TABLE IF NOT EXISTS nft_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    chain_id INTEGER NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    token_id VARCHAR(78) NOT NULL,
+    standard nft_standard NOT NULL,
+    from_address VARCHAR(42) NOT NULL,
+    to_address VARCHAR(42) NOT NULL,
+    quantity BIGINT NOT NULL DEFAULT 1,
+    direction VARCHAR(3) NOT NULL CHECK (direction IN ('in', 'out')),
+    value_usd DECIMAL(30, 10), -- Native value paid/received in the same transaction
+    transaction_hash VARCHAR(66) NOT NULL,
+    block_number BIGINT NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(wallet_id, transaction_hash, contract_address, token_id, direction)
+);
+
+-- Create indexes
+CREATE INDEX idx_nft_transfers_wallet_id ON nft_transfers(wallet_id);
+CREATE INDEX idx_nft_transfers_contract_token ON nft_transfers(contract_address, token_id);
+CREATE INDEX idx_nft_transfers_timestamp ON nft_transfers(timestamp ASC);
@@ -0,0 +1,5 @@
+-- Drop user_api_keys table
+DROP TABLE IF EXISTS user_api_keys;
+
+-- Drop enum type
+DROP TYPE IF EXISTS api_key_provider;
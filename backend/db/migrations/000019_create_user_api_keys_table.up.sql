@@ -0,0 +1,25 @@
+-- Create provider enum for user-supplied API keys
+CREATE TYPE api_key_provider AS ENUM ('alchemy', 'coingecko', 'etherscan', 'infura');
+
+-- Create user_api_keys table (keys are AES-GCM encrypted at rest)
+CREATE TABLE IF NOT EXISTS user_api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider api_key_provider NOT NULL,
+    encrypted_key BYTEA NOT NULL,
+    key_hint VARCHAR(8) NOT NULL, -- Last characters of the key for display
+    usage_count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- One key per provider per user
+    UNIQUE(user_id, provider)
+);
+
+-- Create indexes
+CREATE INDEX idx_user_api_keys_user_id ON user_api_keys(user_id);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_user_api_keys_updated_at BEFORE UPDATE
+    ON user_api_keys FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Drop job_checkpoints table
+DROP TABLE IF EXISTS job_checkpoints;
@@ -0,0 +1,11 @@
+-- Create job_checkpoints table (last processed cursor per worker job)
+CREATE TABLE IF NOT EXISTS job_checkpoints (
+    job_name VARCHAR(100) PRIMARY KEY,
+    last_cursor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_job_checkpoints_updated_at BEFORE UPDATE
+    ON job_checkpoints FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+-- Drop notification queue and settings tables
+DROP TABLE IF EXISTS notification_queue;
+DROP TABLE IF EXISTS user_notification_settings;
+
+-- Drop enum type
+DROP TYPE IF EXISTS quiet_hours_action;
+
+-- Drop mute window
+DROP INDEX IF EXISTS idx_alerts_muted_until;
+ALTER TABLE alerts DROP COLUMN IF EXISTS muted_until;
@@ -0,0 +1,40 @@
+-- Per-alert mute window
+ALTER TABLE alerts ADD COLUMN muted_until TIMESTAMPTZ;
+
+-- What to do with notifications that fall inside quiet hours
+CREATE TYPE quiet_hours_action AS ENUM ('queue', 'drop');
+
+-- Create user_notification_settings table (quiet hours are in the user's timezone)
+CREATE TABLE IF NOT EXISTS user_notification_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    quiet_hours_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    quiet_hours_start VARCHAR(5) NOT NULL DEFAULT '22:00', -- HH:MM local time
+    quiet_hours_end VARCHAR(5) NOT NULL DEFAULT '07:00', -- HH:MM local time
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC', -- IANA name, e.g. Europe/Berlin
+    quiet_hours_action quiet_hours_action NOT NULL DEFAULT 'queue',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_user_notification_settings_updated_at BEFORE UPDATE
+    ON user_notification_settings FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Create notification_queue table for notifications held back by quiet hours
+CREATE TABLE IF NOT EXISTS notification_queue (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    history_id UUID NOT NULL REFERENCES alert_history(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL, -- email, webhook
+    destination TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    deliver_after TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_notification_queue_deliver_after ON notification_queue(deliver_after);
+CREATE INDEX idx_alerts_muted_until ON alerts(muted_until) WHERE muted_until IS NOT NULL;
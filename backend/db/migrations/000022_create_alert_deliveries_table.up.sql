@@ -0,0 +1,18 @@
+-- Create alert_deliveries table: one row per notification attempt outcome
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    history_id UUID NOT NULL REFERENCES alert_history(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL, -- email, webhook
+    status VARCHAR(20) NOT NULL, -- sent, failed, dropped
+    error TEXT,
+    evaluated_at TIMESTAMPTZ NOT NULL,
+    delivered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    latency_ms BIGINT NOT NULL, -- delivered_at - evaluated_at
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_alert_deliveries_user_delivered ON alert_deliveries(user_id, delivered_at DESC);
+CREATE INDEX idx_alert_deliveries_alert_id ON alert_deliveries(alert_id);
@@ -0,0 +1,14 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_user_transactions_user_wallet;
+DROP INDEX IF EXISTS idx_transactions_token_address;
+DROP INDEX IF EXISTS idx_transactions_to_address_lower;
+DROP INDEX IF EXISTS idx_transactions_from_address_lower;
+DROP INDEX IF EXISTS idx_transactions_chain_timestamp;
+DROP INDEX IF EXISTS idx_transactions_timestamp_id;
+DROP INDEX IF EXISTS idx_transactions_method_search;
+
+-- Drop address labels
+DROP TABLE IF EXISTS address_labels;
+
+-- Drop search column
+ALTER TABLE transactions DROP COLUMN IF EXISTS method_search;
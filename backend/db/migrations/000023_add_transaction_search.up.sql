@@ -0,0 +1,30 @@
+-- Searchable decoded method name: camelCase is split into words so
+-- "swapExactTokensForTokens" matches "swap", "exact tokens" etc.
+ALTER TABLE transactions ADD COLUMN method_search tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple', regexp_replace(coalesce(metadata->>'functionName', ''), '([a-z0-9])([A-Z])|[^A-Za-z0-9]+', '\1 \2', 'g'))
+) STORED;
+
+-- Create address_labels table for user-defined counterparty labels
+CREATE TABLE IF NOT EXISTS address_labels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL, -- stored lowercase
+    label VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, address)
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_address_labels_updated_at BEFORE UPDATE
+    ON address_labels FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Create indexes for transaction search
+CREATE INDEX idx_transactions_method_search ON transactions USING GIN(method_search);
+CREATE INDEX idx_transactions_timestamp_id ON transactions(timestamp DESC, id DESC);
+CREATE INDEX idx_transactions_chain_timestamp ON transactions(chain_id, timestamp DESC);
+CREATE INDEX idx_transactions_from_address_lower ON transactions(lower(from_address));
+CREATE INDEX idx_transactions_to_address_lower ON transactions(lower(to_address));
+CREATE INDEX idx_transactions_token_address ON transactions(lower(metadata->>'token_address'));
+CREATE INDEX idx_user_transactions_user_wallet ON user_transactions(user_id, wallet_id);
+CREATE INDEX idx_address_labels_user_label ON address_labels(user_id, lower(label));
@@ -0,0 +1,13 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_balances_block_number;
+DROP INDEX IF EXISTS idx_nft_transfers_chain_block;
+DROP INDEX IF EXISTS idx_transactions_chain_block;
+
+-- Drop reorg events
+DROP TABLE IF EXISTS reorg_events;
+
+-- Drop block hashes
+ALTER TABLE balance_history DROP COLUMN IF EXISTS block_hash;
+ALTER TABLE balances DROP COLUMN IF EXISTS block_hash;
+ALTER TABLE nft_transfers DROP COLUMN IF EXISTS block_hash;
+ALTER TABLE transactions DROP COLUMN IF EXISTS block_hash;
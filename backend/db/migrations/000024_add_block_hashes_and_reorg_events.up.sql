@@ -0,0 +1,24 @@
+-- Track the hash of the block each ingested record came from so reorgs can be detected
+ALTER TABLE transactions ADD COLUMN block_hash VARCHAR(66);
+ALTER TABLE nft_transfers ADD COLUMN block_hash VARCHAR(66);
+ALTER TABLE balances ADD COLUMN block_hash VARCHAR(66);
+ALTER TABLE balance_history ADD COLUMN block_hash VARCHAR(66);
+
+-- Create reorg_events table recording every detected reorg and what was rolled back
+CREATE TABLE IF NOT EXISTS reorg_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chain_id INTEGER NOT NULL,
+    block_number BIGINT NOT NULL,
+    stale_hash VARCHAR(66) NOT NULL,
+    canonical_hash VARCHAR(66) NOT NULL,
+    removed_transactions INT NOT NULL DEFAULT 0,
+    removed_nft_transfers INT NOT NULL DEFAULT 0,
+    removed_balances INT NOT NULL DEFAULT 0,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for recent-block scans
+CREATE INDEX idx_transactions_chain_block ON transactions(chain_id, block_number) WHERE block_hash IS NOT NULL;
+CREATE INDEX idx_nft_transfers_chain_block ON nft_transfers(chain_id, block_number) WHERE block_hash IS NOT NULL;
+CREATE INDEX idx_balances_block_number ON balances(block_number) WHERE block_hash IS NOT NULL;
+CREATE INDEX idx_reorg_events_chain_detected ON reorg_events(chain_id, detected_at DESC);
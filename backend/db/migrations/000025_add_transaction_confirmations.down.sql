@@ -0,0 +1,9 @@
+-- Drop index
+DROP INDEX IF EXISTS idx_transactions_pending;
+
+-- Drop confirmation tracking
+ALTER TABLE transactions DROP COLUMN IF EXISTS status_updated_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS confirmations;
+
+-- Enum values can't be dropped; fold dropped transactions into failed instead
+UPDATE transactions SET status = 'failed' WHERE status = 'dropped';
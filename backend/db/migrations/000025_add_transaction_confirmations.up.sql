@@ -0,0 +1,9 @@
+-- Transactions broadcast but never mined are marked dropped
+ALTER TYPE transaction_status ADD VALUE IF NOT EXISTS 'dropped';
+
+-- Track confirmations of pending transactions until they reach the chain's finality threshold
+ALTER TABLE transactions ADD COLUMN confirmations INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN status_updated_at TIMESTAMPTZ;
+
+-- Create index for the confirmation tracker
+CREATE INDEX idx_transactions_pending ON transactions(created_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_token_metadata_updated_at ON token_metadata;
+
+-- Drop token_metadata table
+DROP TABLE IF EXISTS token_metadata;
@@ -0,0 +1,26 @@
+-- Create token_metadata table holding CoinGecko enrichment for tokens
+CREATE TABLE IF NOT EXISTS token_metadata (
+    token_id UUID PRIMARY KEY REFERENCES tokens(id) ON DELETE CASCADE,
+    coingecko_id VARCHAR(100),
+    categories TEXT[] NOT NULL DEFAULT '{}',
+    description TEXT,
+    website TEXT,
+    twitter VARCHAR(255),
+    telegram VARCHAR(255),
+    discord TEXT,
+    github TEXT,
+    image_url TEXT,
+    circulating_supply DECIMAL(40, 10),
+    -- Set when CoinGecko doesn't list the token so it isn't re-fetched every run
+    not_found BOOLEAN NOT NULL DEFAULT FALSE,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_token_metadata_fetched_at ON token_metadata(fetched_at);
+CREATE INDEX idx_token_metadata_categories ON token_metadata USING GIN(categories);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_token_metadata_updated_at BEFORE UPDATE
+    ON token_metadata FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Drop protocol_tvl_history table
+DROP TABLE IF EXISTS protocol_tvl_history;
@@ -0,0 +1,13 @@
+-- Create protocol_tvl_history table holding DefiLlama TVL snapshots per protocol
+CREATE TABLE IF NOT EXISTS protocol_tvl_history (
+    id BIGSERIAL PRIMARY KEY,
+    protocol_id UUID NOT NULL REFERENCES protocols(id) ON DELETE CASCADE,
+    tvl_usd DECIMAL(30, 2) NOT NULL,
+    -- Per-chain breakdown keyed by DefiLlama chain name, e.g. {"Ethereum": 123.45}
+    chain_tvls JSONB NOT NULL DEFAULT '{}',
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_protocol_tvl_history_protocol_recorded ON protocol_tvl_history(protocol_id, recorded_at DESC);
+CREATE INDEX idx_protocol_tvl_history_recorded_at ON protocol_tvl_history(recorded_at);
@@ -0,0 +1,5 @@
+-- Drop trigger
+DROP TRIGGER IF EXISTS update_liquidation_risk_state_updated_at ON liquidation_risk_state;
+
+-- Drop liquidation_risk_state table
+DROP TABLE IF EXISTS liquidation_risk_state;
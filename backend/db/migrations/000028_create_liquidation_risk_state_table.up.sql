@@ -0,0 +1,18 @@
+-- Create liquidation_risk_state table tracking the escalation level of each health factor alert
+CREATE TABLE IF NOT EXISTS liquidation_risk_state (
+    alert_id UUID PRIMARY KEY REFERENCES alerts(id) ON DELETE CASCADE,
+    level VARCHAR(20) NOT NULL DEFAULT 'ok', -- ok, watch, warning, critical
+    health_factor DECIMAL(20, 6),
+    -- Level last notified, so only escalations and reminders notify again
+    notified_level VARCHAR(20) NOT NULL DEFAULT 'ok',
+    notified_at TIMESTAMPTZ,
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_liquidation_risk_state_level ON liquidation_risk_state(level) WHERE level <> 'ok';
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_liquidation_risk_state_updated_at BEFORE UPDATE
+    ON liquidation_risk_state FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
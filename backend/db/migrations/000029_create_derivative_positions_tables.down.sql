@@ -0,0 +1,3 @@
+-- Drop derivative tables
+DROP TABLE IF EXISTS derivative_funding_payments;
+DROP TABLE IF EXISTS derivative_positions;
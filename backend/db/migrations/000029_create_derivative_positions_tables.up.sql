@@ -0,0 +1,46 @@
+-- Create derivative_positions table holding perpetual positions read from GMX v2 and Hyperliquid
+CREATE TABLE IF NOT EXISTS derivative_positions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_address VARCHAR(42) NOT NULL, -- lowercase
+    venue VARCHAR(20) NOT NULL, -- gmx_v2, hyperliquid
+    chain_id INTEGER, -- NULL for venues with their own chain, e.g. Hyperliquid
+    market VARCHAR(100) NOT NULL, -- GMX market token address or Hyperliquid coin
+    symbol VARCHAR(20) NOT NULL,
+    side VARCHAR(5) NOT NULL, -- long, short
+    size DECIMAL(38, 18) NOT NULL, -- In index tokens
+    size_usd DECIMAL(30, 2) NOT NULL,
+    entry_price DECIMAL(30, 10) NOT NULL,
+    mark_price DECIMAL(30, 10),
+    liquidation_price DECIMAL(30, 10),
+    leverage DECIMAL(10, 2),
+    collateral_usd DECIMAL(30, 2) NOT NULL DEFAULT 0,
+    unrealized_pnl_usd DECIMAL(30, 2) NOT NULL DEFAULT 0,
+    -- Funding accrued since the position opened, positive when received; NULL when the venue doesn't report it
+    funding_since_open_usd DECIMAL(30, 2),
+    is_open BOOLEAN NOT NULL DEFAULT TRUE,
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(wallet_address, venue, market, side)
+);
+
+-- Create derivative_funding_payments table holding settled funding per position
+CREATE TABLE IF NOT EXISTS derivative_funding_payments (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_address VARCHAR(42) NOT NULL, -- lowercase
+    venue VARCHAR(20) NOT NULL,
+    market VARCHAR(100) NOT NULL,
+    amount_usd DECIMAL(30, 6) NOT NULL, -- Positive when received, negative when paid
+    funding_rate DECIMAL(20, 10),
+    position_size DECIMAL(38, 18),
+    paid_at TIMESTAMPTZ NOT NULL,
+    UNIQUE(wallet_address, venue, market, paid_at)
+);
+
+-- Create indexes
+CREATE INDEX idx_derivative_positions_wallet_open ON derivative_positions(wallet_address) WHERE is_open;
+CREATE INDEX idx_derivative_funding_payments_wallet_paid ON derivative_funding_payments(wallet_address, paid_at DESC);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_derivative_positions_updated_at BEFORE UPDATE
+    ON derivative_positions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
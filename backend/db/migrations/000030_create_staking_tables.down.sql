@@ -0,0 +1,3 @@
+-- Drop staking tables
+DROP TABLE IF EXISTS staking_validators;
+DROP TABLE IF EXISTS liquid_staking_positions;
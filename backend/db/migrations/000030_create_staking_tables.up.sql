@@ -0,0 +1,30 @@
+-- Create liquid_staking_positions table holding the principal of each liquid staking token holding
+CREATE TABLE IF NOT EXISTS liquid_staking_positions (
+    wallet_address VARCHAR(42) NOT NULL, -- lowercase
+    chain_id INTEGER NOT NULL,
+    token VARCHAR(42) NOT NULL, -- lowercase token contract
+    -- Non-rebasing units (stETH shares, or the token balance) at the last sync
+    units DECIMAL(38, 18) NOT NULL,
+    -- ETH value of the units when acquired (or first seen); yield accrues on top of it
+    principal_eth DECIMAL(38, 18) NOT NULL,
+    tracked_since TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_address, chain_id, token)
+);
+
+-- Create staking_validators table holding beacon chain validators tracked by solo stakers
+CREATE TABLE IF NOT EXISTS staking_validators (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    validator_index BIGINT NOT NULL,
+    label VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, validator_index)
+);
+
+-- Create indexes
+CREATE INDEX idx_staking_validators_user_id ON staking_validators(user_id);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_liquid_staking_positions_updated_at BEFORE UPDATE
+    ON liquid_staking_positions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+-- Drop non-EVM wallets, which the integer chain ID can't identify
+DELETE FROM wallets WHERE chain_namespace <> 'eip155';
+
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_address_chain_key;
+ALTER TABLE wallets ADD CONSTRAINT wallets_address_chain_id_key UNIQUE (address, chain_id);
+
+ALTER TABLE wallets ALTER COLUMN address TYPE VARCHAR(42);
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS chain_reference;
+ALTER TABLE wallets DROP COLUMN IF EXISTS chain_namespace;
@@ -0,0 +1,13 @@
+-- Identify wallet chains by CAIP-2 namespace and reference so non-EVM chains (e.g.
+-- cosmos:cosmoshub-4) fit alongside EVM chain IDs; non-EVM wallets keep chain_id 0
+ALTER TABLE wallets ADD COLUMN chain_namespace VARCHAR(16) NOT NULL DEFAULT 'eip155';
+ALTER TABLE wallets ADD COLUMN chain_reference VARCHAR(64);
+UPDATE wallets SET chain_reference = chain_id::TEXT;
+ALTER TABLE wallets ALTER COLUMN chain_reference SET NOT NULL;
+
+-- Bech32 addresses are longer than hex ones
+ALTER TABLE wallets ALTER COLUMN address TYPE VARCHAR(128);
+
+-- Replace the EVM-only uniqueness
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_address_chain_id_key;
+ALTER TABLE wallets ADD CONSTRAINT wallets_address_chain_key UNIQUE (address, chain_namespace, chain_reference);
@@ -0,0 +1,6 @@
+-- Drop bitcoin tables
+DROP TABLE IF EXISTS bitcoin_transactions;
+DROP TABLE IF EXISTS bitcoin_accounts;
+
+-- Remove the bitcoin token
+DELETE FROM tokens WHERE address = 'btc' AND chain_id = 0;
@@ -0,0 +1,34 @@
+-- Create bitcoin_accounts table holding Bitcoin addresses and extended public keys tracked read-only
+CREATE TABLE IF NOT EXISTS bitcoin_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('address', 'xpub')),
+    identifier VARCHAR(120) NOT NULL, -- address or xpub/ypub/zpub, case preserved
+    label VARCHAR(100),
+    last_synced_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, identifier)
+);
+
+-- Create bitcoin_transactions table holding each confirmed transaction's net effect on an account
+CREATE TABLE IF NOT EXISTS bitcoin_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES bitcoin_accounts(id) ON DELETE CASCADE,
+    txid CHAR(64) NOT NULL,
+    block_height BIGINT NOT NULL,
+    block_time TIMESTAMPTZ NOT NULL,
+    amount_sats BIGINT NOT NULL, -- negative when spent, including the fee
+    fee_sats BIGINT NOT NULL DEFAULT 0,
+    price_usd DECIMAL(30, 10), -- BTC price on the day of the transaction
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(account_id, txid)
+);
+
+-- Create indexes
+CREATE INDEX idx_bitcoin_accounts_user_id ON bitcoin_accounts(user_id);
+CREATE INDEX idx_bitcoin_transactions_account_time ON bitcoin_transactions(account_id, block_time DESC);
+
+-- Register bitcoin as a token (chain ID 0) so it is priced and can be a price alert target
+INSERT INTO tokens (address, chain_id, symbol, name, decimals)
+VALUES ('btc', 0, 'BTC', 'Bitcoin', 8)
+ON CONFLICT (address, chain_id) DO NOTHING;
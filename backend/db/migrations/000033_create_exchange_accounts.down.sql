@@ -0,0 +1,4 @@
+-- Drop exchange account tables
+DROP TABLE IF EXISTS exchange_trades;
+DROP TABLE IF EXISTS exchange_balances;
+DROP TABLE IF EXISTS exchange_accounts;
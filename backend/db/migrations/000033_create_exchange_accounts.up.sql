@@ -0,0 +1,52 @@
+-- Create exchange_accounts table holding read-only centralized exchange API keys
+CREATE TABLE IF NOT EXISTS exchange_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    exchange VARCHAR(20) NOT NULL CHECK (exchange IN ('binance', 'coinbase', 'kraken')),
+    label VARCHAR(100),
+    encrypted_credentials BYTEA NOT NULL, -- AES-GCM sealed JSON of the key and secret
+    key_hint VARCHAR(8) NOT NULL,
+    last_synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, exchange, key_hint)
+);
+
+-- Create exchange_balances table holding each account's spot balances as last synced
+CREATE TABLE IF NOT EXISTS exchange_balances (
+    account_id UUID NOT NULL REFERENCES exchange_accounts(id) ON DELETE CASCADE,
+    asset VARCHAR(20) NOT NULL,
+    free DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    locked DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, asset)
+);
+
+-- Create exchange_trades table holding executed spot trades
+CREATE TABLE IF NOT EXISTS exchange_trades (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    account_id UUID NOT NULL REFERENCES exchange_accounts(id) ON DELETE CASCADE,
+    trade_id VARCHAR(100) NOT NULL,
+    base_asset VARCHAR(20) NOT NULL,
+    quote_asset VARCHAR(20) NOT NULL,
+    side VARCHAR(4) NOT NULL CHECK (side IN ('buy', 'sell')),
+    quantity DECIMAL(36, 18) NOT NULL,
+    price DECIMAL(36, 18) NOT NULL,
+    fee DECIMAL(36, 18) NOT NULL DEFAULT 0,
+    fee_asset VARCHAR(20),
+    quote_price_usd DECIMAL(30, 10), -- quote asset price on the day of the trade
+    executed_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(account_id, trade_id)
+);
+
+-- Create indexes
+CREATE INDEX idx_exchange_accounts_user_id ON exchange_accounts(user_id);
+CREATE INDEX idx_exchange_trades_account_time ON exchange_trades(account_id, executed_at DESC);
+CREATE INDEX idx_exchange_trades_base_asset ON exchange_trades(base_asset);
+CREATE INDEX idx_exchange_trades_quote_asset ON exchange_trades(quote_asset);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_exchange_accounts_updated_at BEFORE UPDATE
+    ON exchange_accounts FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
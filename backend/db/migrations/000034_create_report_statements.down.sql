@@ -0,0 +1,3 @@
+-- Drop report statement tables
+DROP TABLE IF EXISTS report_statements;
+DROP TABLE IF EXISTS user_report_preferences;
@@ -0,0 +1,28 @@
+-- Create user_report_preferences table (monthly statements are opt-in)
+CREATE TABLE IF NOT EXISTS user_report_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    monthly_enabled BOOLEAN NOT NULL DEFAULT false,
+    email_enabled BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create report_statements table recording generated PDF statements and where they are stored
+CREATE TABLE IF NOT EXISTS report_statements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    storage_key TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    total_value_usd DECIMAL(30, 10) NOT NULL DEFAULT 0,
+    emailed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, period_start)
+);
+
+-- Create indexes
+CREATE INDEX idx_report_statements_user_period ON report_statements(user_id, period_start DESC);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_user_report_preferences_updated_at BEFORE UPDATE
+    ON user_report_preferences FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,16 @@
+-- Move statement file details back onto report_statements
+ALTER TABLE report_statements
+    ADD COLUMN storage_key TEXT,
+    ADD COLUMN size_bytes BIGINT;
+
+UPDATE report_statements s SET storage_key = u.storage_key, size_bytes = u.size_bytes
+FROM uploads u
+WHERE u.id = s.upload_id;
+
+ALTER TABLE report_statements
+    DROP COLUMN upload_id,
+    ALTER COLUMN storage_key SET NOT NULL,
+    ALTER COLUMN size_bytes SET NOT NULL;
+
+-- Drop uploads table
+DROP TABLE IF EXISTS uploads;
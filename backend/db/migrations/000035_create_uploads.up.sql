@@ -0,0 +1,33 @@
+-- Create uploads table tracking files kept in object storage (exports, reports, imports)
+CREATE TABLE IF NOT EXISTS uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('export', 'report', 'import')),
+    storage_key TEXT NOT NULL UNIQUE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_uploads_user_created ON uploads(user_id, created_at DESC);
+CREATE INDEX idx_uploads_expires_at ON uploads(expires_at) WHERE expires_at IS NOT NULL;
+
+-- Statements now reference their file through uploads
+INSERT INTO uploads (user_id, kind, storage_key, filename, content_type, size_bytes, created_at)
+SELECT user_id, 'report', storage_key, 'statement_' || to_char(period_start AT TIME ZONE 'UTC', 'YYYY-MM') || '.pdf',
+       'application/pdf', size_bytes, created_at
+FROM report_statements;
+
+ALTER TABLE report_statements ADD COLUMN upload_id UUID REFERENCES uploads(id) ON DELETE CASCADE;
+
+UPDATE report_statements s SET upload_id = u.id
+FROM uploads u
+WHERE u.storage_key = s.storage_key;
+
+ALTER TABLE report_statements
+    ALTER COLUMN upload_id SET NOT NULL,
+    DROP COLUMN storage_key,
+    DROP COLUMN size_bytes;
@@ -0,0 +1,8 @@
+-- Drop transaction imports and manual transactions
+DROP TABLE IF EXISTS transaction_imports;
+
+DELETE FROM transactions WHERE source = 'manual';
+
+DROP INDEX IF EXISTS idx_transactions_manual;
+ALTER TABLE pnl_lots DROP COLUMN IF EXISTS source;
+ALTER TABLE transactions DROP COLUMN IF EXISTS source;
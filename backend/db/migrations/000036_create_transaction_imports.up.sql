@@ -0,0 +1,30 @@
+-- Record where transactions and lots come from: synced on-chain or entered manually
+ALTER TABLE transactions ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'onchain'
+    CHECK (source IN ('onchain', 'manual'));
+ALTER TABLE pnl_lots ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'onchain'
+    CHECK (source IN ('onchain', 'manual'));
+
+CREATE INDEX idx_transactions_manual ON transactions(source) WHERE source = 'manual';
+
+-- Create transaction_imports table tracking CSV imports of manual transactions
+CREATE TABLE IF NOT EXISTS transaction_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    upload_id UUID REFERENCES uploads(id) ON DELETE SET NULL,
+    filename TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'previewed', 'committed')),
+    column_mapping JSONB,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    imported_count INTEGER NOT NULL DEFAULT 0,
+    committed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_transaction_imports_user ON transaction_imports(user_id, created_at DESC);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_transaction_imports_updated_at BEFORE UPDATE
+    ON transaction_imports FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Drop income overrides
+DROP TABLE IF EXISTS income_overrides;
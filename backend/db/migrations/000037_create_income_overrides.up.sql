@@ -0,0 +1,18 @@
+-- Create income_overrides table holding users' income classifications of inbound
+-- transfers, which take precedence over the protocol heuristics
+CREATE TABLE IF NOT EXISTS income_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_hash VARCHAR(66) NOT NULL REFERENCES transactions(hash) ON DELETE CASCADE,
+    -- 'not_income' marks a transfer the heuristics would otherwise count as income
+    category VARCHAR(20) NOT NULL CHECK (category IN (
+        'staking_reward', 'airdrop', 'interest', 'cashback', 'mining', 'other', 'not_income'
+    )),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, transaction_hash)
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_income_overrides_updated_at BEFORE UPDATE
+    ON income_overrides FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,23 @@
+-- Restore income overrides from annotation categories
+CREATE TABLE IF NOT EXISTS income_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_hash VARCHAR(66) NOT NULL REFERENCES transactions(hash) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL CHECK (category IN (
+        'staking_reward', 'airdrop', 'interest', 'cashback', 'mining', 'other', 'not_income'
+    )),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, transaction_hash)
+);
+
+CREATE TRIGGER update_income_overrides_updated_at BEFORE UPDATE
+    ON income_overrides FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO income_overrides (user_id, transaction_hash, category, created_at, updated_at)
+SELECT a.user_id, a.transaction_hash, a.category, a.created_at, a.updated_at
+FROM transaction_annotations a
+WHERE a.category IS NOT NULL
+  AND EXISTS (SELECT 1 FROM transactions t WHERE t.hash = a.transaction_hash);
+
+DROP TABLE IF EXISTS transaction_annotations;
@@ -0,0 +1,31 @@
+-- Create transaction_annotations table holding users' categories, tags and notes on
+-- transactions. Annotations are keyed by hash, so transactions listed straight from a
+-- provider can be annotated before they are stored.
+CREATE TABLE IF NOT EXISTS transaction_annotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_hash VARCHAR(66) NOT NULL,
+    -- Overrides the PnL engine's income classification; 'not_income' excludes a transfer
+    category VARCHAR(20) CHECK (category IN (
+        'staking_reward', 'airdrop', 'interest', 'cashback', 'mining', 'other', 'not_income'
+    )),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, transaction_hash)
+);
+
+-- Create indexes
+CREATE INDEX idx_transaction_annotations_tags ON transaction_annotations USING GIN(tags);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_transaction_annotations_updated_at BEFORE UPDATE
+    ON transaction_annotations FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Income overrides become annotation categories
+INSERT INTO transaction_annotations (user_id, transaction_hash, category, created_at, updated_at)
+SELECT user_id, transaction_hash, category, created_at, updated_at
+FROM income_overrides;
+
+DROP TABLE IF EXISTS income_overrides;
@@ -0,0 +1,3 @@
+-- Drop internal transfer matching
+ALTER TABLE pnl_lots DROP COLUMN IF EXISTS internal_transfer_id;
+DROP TABLE IF EXISTS internal_transfers;
@@ -0,0 +1,15 @@
+-- Create internal_transfers table pairing a transfer out of one of a user's wallets with
+-- its arrival in another. Paired lots move holdings without realizing PnL.
+CREATE TABLE IF NOT EXISTS internal_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    out_lot_id UUID NOT NULL UNIQUE REFERENCES pnl_lots(id) ON DELETE CASCADE,
+    in_lot_id UUID NOT NULL UNIQUE REFERENCES pnl_lots(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE pnl_lots ADD COLUMN internal_transfer_id UUID REFERENCES internal_transfers(id) ON DELETE SET NULL;
+
+-- Create indexes
+CREATE INDEX idx_internal_transfers_user_id ON internal_transfers(user_id);
+CREATE INDEX idx_pnl_lots_unmatched_transfers ON pnl_lots(wallet_id, timestamp) WHERE internal_transfer_id IS NULL;
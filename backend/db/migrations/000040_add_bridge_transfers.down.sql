@@ -0,0 +1,3 @@
+-- Drop bridge transfer matching
+ALTER TABLE pnl_lots DROP COLUMN IF EXISTS cost_basis_usd;
+ALTER TABLE internal_transfers DROP COLUMN IF EXISTS kind;
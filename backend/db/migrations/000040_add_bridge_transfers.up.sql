@@ -0,0 +1,7 @@
+-- Bridge transfers pair a transfer leaving one chain with its arrival on another. Paired
+-- arrivals carry the cost basis of the lots they left.
+ALTER TABLE internal_transfers ADD COLUMN kind VARCHAR(10) NOT NULL DEFAULT 'wallet'
+    CHECK (kind IN ('wallet', 'bridge'));
+
+-- Per-unit cost basis carried in by a transfer, used instead of price_usd when set
+ALTER TABLE pnl_lots ADD COLUMN cost_basis_usd DECIMAL(30, 10);
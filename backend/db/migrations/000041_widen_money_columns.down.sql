@@ -0,0 +1,15 @@
+-- Restore the original precision, rounding values to fit
+ALTER TABLE yield_pools
+    ALTER COLUMN apy TYPE DECIMAL(10, 4) USING ROUND(apy, 4),
+    ALTER COLUMN apy_base TYPE DECIMAL(10, 4) USING ROUND(apy_base, 4),
+    ALTER COLUMN apy_reward TYPE DECIMAL(10, 4) USING ROUND(apy_reward, 4),
+    ALTER COLUMN fees_apr TYPE DECIMAL(10, 4) USING ROUND(fees_apr, 4);
+
+ALTER TABLE positions
+    ALTER COLUMN balance_usd TYPE DECIMAL(20, 8) USING ROUND(balance_usd, 8),
+    ALTER COLUMN entry_price_usd TYPE DECIMAL(20, 8) USING ROUND(entry_price_usd, 8),
+    ALTER COLUMN total_rewards_usd TYPE DECIMAL(20, 8) USING ROUND(total_rewards_usd, 8),
+    ALTER COLUMN current_value_usd TYPE DECIMAL(20, 8) USING ROUND(current_value_usd, 8),
+    ALTER COLUMN unrealized_pnl_usd TYPE DECIMAL(20, 8) USING ROUND(unrealized_pnl_usd, 8),
+    ALTER COLUMN realized_pnl_usd TYPE DECIMAL(20, 8) USING ROUND(realized_pnl_usd, 8),
+    ALTER COLUMN total_fees_paid_usd TYPE DECIMAL(20, 8) USING ROUND(total_fees_paid_usd, 8);
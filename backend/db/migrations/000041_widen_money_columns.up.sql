@@ -0,0 +1,18 @@
+-- Money columns are read into exact decimals; widen the ones narrower than the
+-- DECIMAL(30, 10) used for USD amounts elsewhere so sums and rates aren't cut short.
+-- Positions held USD at 8 places and rates at 4, which rounded small positions and
+-- low-yield pools before any arithmetic happened.
+ALTER TABLE positions
+    ALTER COLUMN balance_usd TYPE DECIMAL(30, 10),
+    ALTER COLUMN entry_price_usd TYPE DECIMAL(30, 10),
+    ALTER COLUMN total_rewards_usd TYPE DECIMAL(30, 10),
+    ALTER COLUMN current_value_usd TYPE DECIMAL(30, 10),
+    ALTER COLUMN unrealized_pnl_usd TYPE DECIMAL(30, 10),
+    ALTER COLUMN realized_pnl_usd TYPE DECIMAL(30, 10),
+    ALTER COLUMN total_fees_paid_usd TYPE DECIMAL(30, 10);
+
+ALTER TABLE yield_pools
+    ALTER COLUMN apy TYPE DECIMAL(20, 10),
+    ALTER COLUMN apy_base TYPE DECIMAL(20, 10),
+    ALTER COLUMN apy_reward TYPE DECIMAL(20, 10),
+    ALTER COLUMN fees_apr TYPE DECIMAL(20, 10);
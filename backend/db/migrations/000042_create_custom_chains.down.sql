@@ -0,0 +1,2 @@
+-- Drop custom chains
+DROP TABLE IF EXISTS custom_chains;
@@ -0,0 +1,20 @@
+-- Create custom_chains table holding EVM chains added by configuration. Balances,
+-- transactions and gas for these chains are read through the chain's own RPC endpoint.
+CREATE TABLE IF NOT EXISTS custom_chains (
+    chain_id INTEGER PRIMARY KEY CHECK (chain_id > 0),
+    name VARCHAR(100) NOT NULL,
+    rpc_url TEXT NOT NULL,
+    explorer_url TEXT,
+    native_symbol VARCHAR(20) NOT NULL,
+    -- CoinGecko ID of the gas token, used to price gas fees and native balances
+    native_coingecko_id VARCHAR(100),
+    is_testnet BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Set when a user rather than an admin registered the chain
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_custom_chains_updated_at BEFORE UPDATE
+    ON custom_chains FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Drop the wallet testnet flag
+DROP INDEX IF EXISTS idx_wallets_user_testnet;
+ALTER TABLE wallets DROP COLUMN IF EXISTS is_testnet;
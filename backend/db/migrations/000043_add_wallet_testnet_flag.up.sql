@@ -0,0 +1,9 @@
+-- Testnet wallets are kept out of mainnet portfolio totals, statements and transfer
+-- matching unless testnets are asked for
+ALTER TABLE wallets ADD COLUMN is_testnet BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE wallets SET is_testnet = TRUE
+WHERE chain_id = 80002
+   OR chain_id IN (SELECT chain_id FROM custom_chains WHERE is_testnet);
+
+CREATE INDEX idx_wallets_user_testnet ON wallets(user_id, is_testnet);
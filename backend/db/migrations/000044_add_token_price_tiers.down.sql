@@ -0,0 +1,4 @@
+-- Drop token price tiering
+DROP INDEX IF EXISTS idx_tokens_last_updated;
+ALTER TABLE tokens DROP COLUMN IF EXISTS price_boosted_until;
+ALTER TABLE tokens DROP COLUMN IF EXISTS price_tier;
@@ -0,0 +1,7 @@
+-- The price refresh job refreshes tokens by tier: held, alerted and recently viewed
+-- tokens every run, the long tail on a slower cadence. last_updated records when a
+-- token's price was last refreshed.
+ALTER TABLE tokens ADD COLUMN price_tier VARCHAR(10);
+ALTER TABLE tokens ADD COLUMN price_boosted_until TIMESTAMPTZ;
+
+CREATE INDEX idx_tokens_last_updated ON tokens(last_updated NULLS FIRST);
@@ -0,0 +1,2 @@
+-- Drop wallet_valuations table
+DROP TABLE IF EXISTS wallet_valuations;
@@ -0,0 +1,15 @@
+-- Create wallet_valuations table holding periodic USD valuations of each mainnet wallet.
+-- Every row of one snapshot run shares its recorded_at, so a user's portfolio value at a
+-- point in time is the sum of their wallets' rows at that recorded_at.
+CREATE TABLE IF NOT EXISTS wallet_valuations (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    value_usd DECIMAL(30, 10) NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_wallet_valuations_user_recorded ON wallet_valuations(user_id, recorded_at DESC);
+CREATE INDEX idx_wallet_valuations_wallet_recorded ON wallet_valuations(wallet_id, recorded_at DESC);
+CREATE INDEX idx_wallet_valuations_recorded_at ON wallet_valuations(recorded_at);
@@ -0,0 +1,2 @@
+-- Drop yield position entry token prices
+ALTER TABLE yield_positions DROP COLUMN IF EXISTS entry_token_prices;
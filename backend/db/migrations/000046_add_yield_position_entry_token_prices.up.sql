@@ -0,0 +1,3 @@
+-- USD price of each pool token when a yield position was entered, keyed by lowercase
+-- token address, so impermanent loss can be measured against holding the tokens
+ALTER TABLE yield_positions ADD COLUMN entry_token_prices JSONB;
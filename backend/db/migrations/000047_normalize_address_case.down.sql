@@ -0,0 +1,3 @@
+-- Drop nothing: the original mixed-case addresses are not recoverable and lowercase
+-- rows remain valid
+SELECT 1;
@@ -0,0 +1,39 @@
+-- Store EVM addresses lowercase so lookups can compare them directly.
+-- Rows whose lowercase form already exists are left for manual review rather than
+-- tripping the unique constraints.
+UPDATE users u SET address = LOWER(u.address)
+WHERE u.address ~ '^0[xX][0-9a-fA-F]{40}$' AND u.address <> LOWER(u.address)
+  AND NOT EXISTS (SELECT 1 FROM users o WHERE o.address = LOWER(u.address));
+
+UPDATE nonce_storage SET address = LOWER(address)
+WHERE address ~ '^0[xX][0-9a-fA-F]{40}$' AND address <> LOWER(address);
+
+UPDATE wallets w SET address = LOWER(w.address)
+WHERE w.chain_namespace = 'eip155' AND w.address <> LOWER(w.address)
+  AND NOT EXISTS (
+    SELECT 1 FROM wallets o
+    WHERE o.address = LOWER(w.address) AND o.chain_namespace = w.chain_namespace
+      AND o.chain_reference = w.chain_reference
+  );
+
+UPDATE tokens t SET address = LOWER(t.address)
+WHERE t.address ~ '^0[xX][0-9a-fA-F]{40}$' AND t.address <> LOWER(t.address)
+  AND NOT EXISTS (SELECT 1 FROM tokens o WHERE o.address = LOWER(t.address) AND o.chain_id = t.chain_id);
+
+UPDATE transactions SET from_address = LOWER(from_address)
+WHERE from_address <> LOWER(from_address);
+
+UPDATE transactions SET to_address = LOWER(to_address)
+WHERE to_address <> LOWER(to_address);
+
+UPDATE token_allowances a SET spender_address = LOWER(a.spender_address)
+WHERE a.spender_address <> LOWER(a.spender_address)
+  AND NOT EXISTS (
+    SELECT 1 FROM token_allowances o
+    WHERE o.wallet_id = a.wallet_id AND o.token_id = a.token_id
+      AND o.spender_address = LOWER(a.spender_address)
+  );
+
+UPDATE address_labels l SET address = LOWER(l.address)
+WHERE l.address ~ '^0[xX][0-9a-fA-F]{40}$' AND l.address <> LOWER(l.address)
+  AND NOT EXISTS (SELECT 1 FROM address_labels o WHERE o.user_id = l.user_id AND o.address = LOWER(l.address));
@@ -0,0 +1,4 @@
+-- Drop notification outbox
+DROP INDEX IF EXISTS idx_alert_deliveries_sent_delivery_id;
+ALTER TABLE alert_deliveries DROP COLUMN IF EXISTS delivery_id;
+DROP TABLE IF EXISTS notification_outbox;
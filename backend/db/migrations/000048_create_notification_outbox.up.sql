@@ -0,0 +1,23 @@
+-- Create notification_outbox table: one row per triggered alert, written in the same
+-- transaction as its alert_history row and removed from the pending set once dispatched
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    history_id UUID NOT NULL UNIQUE REFERENCES alert_history(id) ON DELETE CASCADE,
+    notification JSONB NOT NULL, -- channels to deliver to, as of the trigger
+    urgent BOOLEAN NOT NULL DEFAULT FALSE, -- delivered through quiet hours
+    attempts INT NOT NULL DEFAULT 0,
+    claimed_until TIMESTAMPTZ, -- lease held by the dispatcher working on the row
+    last_error TEXT,
+    dispatched_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_notification_outbox_pending ON notification_outbox(created_at) WHERE dispatched_at IS NULL;
+CREATE INDEX idx_notification_outbox_dispatched_at ON notification_outbox(dispatched_at) WHERE dispatched_at IS NOT NULL;
+
+-- Deliveries carry a stable ID per history row and channel so a retried dispatch can
+-- tell what was already sent
+ALTER TABLE alert_deliveries ADD COLUMN delivery_id UUID;
+CREATE UNIQUE INDEX idx_alert_deliveries_sent_delivery_id ON alert_deliveries(delivery_id) WHERE status = 'sent';
@@ -0,0 +1,2 @@
+-- Drop provider_policies table
+DROP TABLE IF EXISTS provider_policies;
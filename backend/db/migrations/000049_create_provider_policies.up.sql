@@ -0,0 +1,27 @@
+-- Create provider_policies table holding admin overrides for quote aggregator providers.
+-- A policy applies to one provider (lifi, socket, 0x, 1inch) or to all of them ('*'),
+-- on one chain or on every chain when chain_id is NULL. A disabled '*' policy with no
+-- chain is the emergency kill switch for all quoting.
+CREATE TABLE IF NOT EXISTS provider_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(32) NOT NULL,
+    chain_id INTEGER CHECK (chain_id > 0),
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Multiplies the provider's output amount when routes are ranked
+    weight NUMERIC(10, 4) CHECK (weight > 0),
+    -- Slippage sent to the provider is capped at this percentage
+    max_slippage NUMERIC(6, 3) CHECK (max_slippage > 0),
+    -- Routes whose fees exceed this many USD are dropped
+    max_fee_usd NUMERIC(20, 2) CHECK (max_fee_usd >= 0),
+    reason TEXT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One policy per provider and chain, counting "every chain" as a chain
+CREATE UNIQUE INDEX idx_provider_policies_scope ON provider_policies(provider, COALESCE(chain_id, 0));
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_provider_policies_updated_at BEFORE UPDATE
+    ON provider_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
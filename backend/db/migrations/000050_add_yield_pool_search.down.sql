@@ -0,0 +1,5 @@
+-- Drop yield pool search
+DROP INDEX IF EXISTS idx_yield_pools_tvl;
+DROP INDEX IF EXISTS idx_yield_pools_apy;
+DROP INDEX IF EXISTS idx_yield_pools_search;
+ALTER TABLE yield_pools DROP COLUMN IF EXISTS search;
@@ -0,0 +1,12 @@
+-- Searchable pool name, symbol and protocol. Symbols like "WETH-USDC" are split so
+-- either token matches.
+ALTER TABLE yield_pools ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    to_tsvector('simple',
+        coalesce(pool_name, '') || ' ' ||
+        regexp_replace(coalesce(symbol, ''), '[^A-Za-z0-9]+', ' ', 'g') || ' ' ||
+        coalesce(protocol, ''))
+) STORED;
+
+CREATE INDEX idx_yield_pools_search ON yield_pools USING GIN(search);
+CREATE INDEX idx_yield_pools_apy ON yield_pools(apy DESC NULLS LAST);
+CREATE INDEX idx_yield_pools_tvl ON yield_pools(tvl_usd DESC NULLS LAST);
@@ -0,0 +1,20 @@
+-- Create saved_searches table holding named filter sets for the yield explorer and the
+-- transaction list. params holds the query parameters of the matching search endpoint.
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('yield_pools', 'transactions')),
+    params JSONB NOT NULL DEFAULT '{}',
+    -- Set while the search is shared; anyone with the token can read it
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, kind, name)
+);
+
+CREATE INDEX idx_saved_searches_user_id ON saved_searches(user_id);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_saved_searches_updated_at BEFORE UPDATE
+    ON saved_searches FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+-- Drop reward_locks table
+DROP TABLE IF EXISTS reward_locks;
@@ -0,0 +1,36 @@
+-- Create reward_locks table holding tokens locked for voting power (veCRV-style escrows,
+-- read from the chain) or vesting on a schedule (escrowed rewards, entered by the user)
+CREATE TABLE IF NOT EXISTS reward_locks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wallet_address VARCHAR(42) NOT NULL, -- lowercase
+    chain_id INTEGER NOT NULL,
+    protocol VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('vote_escrow', 'vesting')),
+    source VARCHAR(20) NOT NULL CHECK (source IN ('onchain', 'manual')),
+    contract VARCHAR(42) NOT NULL, -- lowercase escrow or vesting contract
+    token_address VARCHAR(42) NOT NULL, -- lowercase
+    token_symbol VARCHAR(20) NOT NULL,
+    amount DECIMAL(38, 18) NOT NULL,
+    -- Vested tokens already claimed into the wallet
+    released DECIMAL(38, 18) NOT NULL DEFAULT 0,
+    voting_power DECIMAL(38, 18),
+    -- Vesting starts at starts_at and releases linearly until unlocks_at, nothing before
+    -- cliff_at. Vote-escrow locks release everything at unlocks_at.
+    starts_at TIMESTAMPTZ,
+    cliff_at TIMESTAMPTZ,
+    unlocks_at TIMESTAMPTZ NOT NULL,
+    synced_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reward_locks_user_id ON reward_locks(user_id, unlocks_at);
+
+-- A wallet has one lock per escrow contract
+CREATE UNIQUE INDEX idx_reward_locks_onchain ON reward_locks(user_id, wallet_address, chain_id, contract)
+    WHERE source = 'onchain';
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_reward_locks_updated_at BEFORE UPDATE
+    ON reward_locks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
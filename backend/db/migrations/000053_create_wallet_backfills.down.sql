@@ -0,0 +1,2 @@
+-- Drop wallet_backfills table
+DROP TABLE IF EXISTS wallet_backfills;
@@ -0,0 +1,27 @@
+-- Create wallet_backfills table tracking the import of each EVM wallet's transaction
+-- history, from genesis up to the chain head when the backfill started
+CREATE TABLE IF NOT EXISTS wallet_backfills (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL UNIQUE REFERENCES wallets(id) ON DELETE CASCADE,
+    chain_id INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    -- Chain head when the backfill started; later blocks are left to the incremental syncs
+    target_block BIGINT,
+    -- First block not yet imported
+    next_block BIGINT NOT NULL DEFAULT 0,
+    transactions_found INTEGER NOT NULL DEFAULT 0,
+    -- Consecutive chunks that failed; the backfill fails after too many
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_wallet_backfills_active ON wallet_backfills(created_at)
+    WHERE status IN ('pending', 'running');
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_wallet_backfills_updated_at BEFORE UPDATE
+    ON wallet_backfills FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+-- Drop wallet groups
+ALTER TABLE wallets DROP COLUMN IF EXISTS group_id;
+DROP TABLE IF EXISTS wallet_groups;
@@ -0,0 +1,22 @@
+-- Create wallet_groups table holding users' named groups of wallets ("Long-term",
+-- "DAO treasury"), each viewed as a portfolio of its own
+CREATE TABLE IF NOT EXISTS wallet_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX idx_wallet_groups_user_id ON wallet_groups(user_id);
+
+-- A wallet belongs to at most one group; deleting the group ungroups its wallets
+ALTER TABLE wallets ADD COLUMN group_id UUID REFERENCES wallet_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_wallets_group_id ON wallets(group_id) WHERE group_id IS NOT NULL;
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_wallet_groups_updated_at BEFORE UPDATE
+    ON wallet_groups FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
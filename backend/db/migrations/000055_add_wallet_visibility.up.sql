@@ -0,0 +1,4 @@
+-- Private wallets stay out of anything shown to other people: shared links and
+-- aggregations across users. Their owner still sees them everywhere.
+ALTER TABLE wallets ADD COLUMN visibility VARCHAR(10) NOT NULL DEFAULT 'visible'
+    CHECK (visibility IN ('visible', 'private'));
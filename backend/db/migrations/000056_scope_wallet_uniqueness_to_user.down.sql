@@ -0,0 +1,4 @@
+-- Fails while two users track the same address on the same chain
+DROP INDEX IF EXISTS idx_wallets_address_chain;
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_user_address_chain_key;
+ALTER TABLE wallets ADD CONSTRAINT wallets_address_chain_key UNIQUE (address, chain_namespace, chain_reference);
@@ -0,0 +1,7 @@
+-- Several users may track the same address, so an address is unique per user rather than
+-- globally. A user adding it on another chain gets a wallet row per chain.
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_address_chain_key;
+ALTER TABLE wallets ADD CONSTRAINT wallets_user_address_chain_key UNIQUE (user_id, address, chain_namespace, chain_reference);
+
+-- Look up the other wallets tracking an address, whose imported history can be shared
+CREATE INDEX idx_wallets_address_chain ON wallets(address, chain_namespace, chain_reference);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_wallets_account_type_pending;
+ALTER TABLE wallets DROP COLUMN IF EXISTS account_type;
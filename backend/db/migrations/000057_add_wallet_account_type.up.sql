@@ -0,0 +1,7 @@
+-- What the wallet's address holds on chain, detected after the wallet is added; NULL
+-- until then. Contract accounts verify signatures with EIP-1271.
+ALTER TABLE wallets ADD COLUMN account_type VARCHAR(20)
+    CHECK (account_type IN ('eoa', 'safe', 'erc4337', 'contract'));
+
+CREATE INDEX idx_wallets_account_type_pending ON wallets(created_at)
+    WHERE account_type IS NULL AND chain_namespace = 'eip155';
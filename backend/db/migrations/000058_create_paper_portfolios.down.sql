@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS paper_trades;
+DROP TABLE IF EXISTS paper_portfolios;
@@ -0,0 +1,31 @@
+-- Create paper_portfolios table holding simulated portfolios: a cash balance users trade
+-- at live prices without real wallets, to test strategies or try the app out
+CREATE TABLE IF NOT EXISTS paper_portfolios (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    starting_cash_usd DECIMAL(30, 10) NOT NULL CHECK (starting_cash_usd > 0),
+    cash_usd DECIMAL(30, 10) NOT NULL CHECK (cash_usd >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+-- Create paper_trades table holding the simulated buys, sells and yield received
+CREATE TABLE IF NOT EXISTS paper_trades (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES paper_portfolios(id) ON DELETE CASCADE,
+    side VARCHAR(5) NOT NULL CHECK (side IN ('buy', 'sell', 'yield')),
+    asset VARCHAR(20) NOT NULL,
+    quantity DECIMAL(36, 18) NOT NULL CHECK (quantity > 0),
+    price_usd DECIMAL(30, 10) NOT NULL, -- live price the trade executed at
+    executed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_paper_portfolios_user_id ON paper_portfolios(user_id);
+CREATE INDEX idx_paper_trades_portfolio_time ON paper_trades(portfolio_id, executed_at);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_paper_portfolios_updated_at BEFORE UPDATE
+    ON paper_portfolios FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS leaderboard_participants;
@@ -0,0 +1,8 @@
+-- Create leaderboard_participants table holding the users who opted in to the public
+-- leaderboards. Users appear there only under their generated alias, never by address.
+CREATE TABLE IF NOT EXISTS leaderboard_participants (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    alias VARCHAR(50) NOT NULL UNIQUE,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE, -- set by admins to take a user off the boards
+    opted_in_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
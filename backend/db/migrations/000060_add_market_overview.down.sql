@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS trending_coins;
+
+ALTER TABLE yield_pools
+DROP COLUMN IF EXISTS apy_pct_7d,
+DROP COLUMN IF EXISTS apy_pct_1d;
@@ -0,0 +1,19 @@
+-- Keep DefiLlama's APY changes on each pool, in percentage points, for the biggest APY
+-- movers on the market overview
+ALTER TABLE yield_pools
+ADD COLUMN IF NOT EXISTS apy_pct_1d DECIMAL(20, 10),
+ADD COLUMN IF NOT EXISTS apy_pct_7d DECIMAL(20, 10);
+
+-- Create trending_coins table holding CoinGecko's trending coins as of the last price
+-- refresh, replaced as a whole on every refresh
+CREATE TABLE IF NOT EXISTS trending_coins (
+    rank INTEGER PRIMARY KEY, -- 1 for the most searched
+    coingecko_id VARCHAR(100) NOT NULL,
+    symbol VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    market_cap_rank INTEGER,
+    thumb_url TEXT,
+    price_usd DECIMAL(30, 10),
+    price_change_24h DECIMAL(20, 4),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
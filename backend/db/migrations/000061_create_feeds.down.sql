@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS feed_items;
+DROP TABLE IF EXISTS feed_sources;
@@ -0,0 +1,51 @@
+-- Create feed_sources table holding the RSS feeds and Discourse forums whose posts make
+-- up the news feed. A source is tied to the protocol and tokens it talks about, so the
+-- feed can be narrowed to what a user holds.
+CREATE TABLE IF NOT EXISTS feed_sources (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('rss', 'discourse')),
+    url TEXT NOT NULL UNIQUE,
+    protocol_id UUID REFERENCES protocols(id) ON DELETE SET NULL,
+    tokens TEXT[] NOT NULL DEFAULT '{}', -- upper case symbols
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_fetched_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create feed_items table holding the posts ingested from the sources. The same post
+-- syndicated by several sources is kept once, by URL.
+CREATE TABLE IF NOT EXISTS feed_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_id UUID NOT NULL REFERENCES feed_sources(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    url TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    summary TEXT,
+    published_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (source_id, guid)
+);
+
+-- Create indexes
+CREATE INDEX idx_feed_items_published_at ON feed_items(published_at DESC, id DESC);
+CREATE INDEX idx_feed_sources_protocol_id ON feed_sources(protocol_id);
+CREATE INDEX idx_feed_sources_tokens ON feed_sources USING GIN (tokens);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_feed_sources_updated_at BEFORE UPDATE
+    ON feed_sources FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Governance forums of the default protocols
+INSERT INTO feed_sources (name, kind, url, protocol_id, tokens)
+SELECT s.name, 'discourse', s.url, p.id, s.tokens
+FROM (VALUES
+    ('Aave Governance', 'https://governance.aave.com', 'aave-v3', ARRAY['AAVE']),
+    ('Uniswap Governance', 'https://gov.uniswap.org', 'uniswap-v3', ARRAY['UNI']),
+    ('Compound Community', 'https://www.comp.xyz', 'compound-v3', ARRAY['COMP']),
+    ('Lido Research', 'https://research.lido.fi', 'lido', ARRAY['LDO', 'STETH', 'WSTETH'])
+) AS s(name, url, slug, tokens)
+LEFT JOIN protocols p ON p.slug = s.slug
+ON CONFLICT (url) DO NOTHING;
@@ -0,0 +1,33 @@
+-- Create email_deliveries table tracking every email sent, or not sent, through the
+-- email provider. Provider bounce and complaint notifications update a delivery by the
+-- message ID the provider returned.
+CREATE TABLE IF NOT EXISTS email_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template VARCHAR(50) NOT NULL,
+    to_address TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    provider_message_id TEXT,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sent', 'failed', 'suppressed', 'delivered', 'bounced', 'complained')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create email_suppressions table listing the addresses nothing is sent to: hard
+-- bounces, spam complaints and addresses added by admins. Addresses are lower case.
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email TEXT PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('hard_bounce', 'complaint', 'manual')),
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_email_deliveries_provider_message_id ON email_deliveries(provider, provider_message_id);
+CREATE INDEX idx_email_deliveries_to_address ON email_deliveries(LOWER(to_address), created_at DESC);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_email_deliveries_updated_at BEFORE UPDATE
+    ON email_deliveries FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
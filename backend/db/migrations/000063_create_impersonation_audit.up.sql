@@ -0,0 +1,32 @@
+-- Create impersonation_sessions table. An admin opens a session to view one user's data
+-- read-only for support; the impersonation token issued with it names the session, so
+-- ending the session revokes the token.
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create audit_log table recording what admins do on users' behalf. Entries are never
+-- updated, and are kept when the users involved are deleted.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_user_id UUID,
+    session_id UUID,
+    method VARCHAR(10),
+    path TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_impersonation_sessions_admin_id ON impersonation_sessions(admin_id, created_at DESC);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at DESC, id DESC);
+CREATE INDEX idx_audit_log_actor_id ON audit_log(actor_id, created_at DESC);
+CREATE INDEX idx_audit_log_target_user_id ON audit_log(target_user_id, created_at DESC);
@@ -0,0 +1,20 @@
+-- Create usage_flags table. A flag records API usage that looked like scraping, from a
+-- user or a provider API key sent by requests, and limits the subject more strictly
+-- until restricted_until unless an admin resolves it first.
+CREATE TABLE IF NOT EXISTS usage_flags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subject_type VARCHAR(20) NOT NULL CHECK (subject_type IN ('user', 'api_key')),
+    subject VARCHAR(100) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL CHECK (reason IN ('address_enumeration', 'request_burst')),
+    details JSONB NOT NULL DEFAULT '{}',
+    restricted_until TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_usage_flags_created_at ON usage_flags(created_at DESC, id DESC);
+CREATE INDEX idx_usage_flags_active ON usage_flags(restricted_until) WHERE resolved_at IS NULL;
+CREATE INDEX idx_usage_flags_user_id ON usage_flags(user_id, created_at DESC);
@@ -0,0 +1,9 @@
+-- Create webhook_verifications table. A user's webhook URL is only delivered to once it
+-- has answered a verification challenge; URLs are stored as their SHA-256 hash, as they
+-- often carry a secret token.
+CREATE TABLE IF NOT EXISTS webhook_verifications (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url_hash VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, url_hash)
+);
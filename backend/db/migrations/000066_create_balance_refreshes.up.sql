@@ -0,0 +1,28 @@
+-- Create balance_refreshes table. A refresh re-fetches the balances of a set of EVM
+-- wallets on an admin's request: the wallets listed, or every wallet (on one chain if
+-- given). Wallets are refreshed in chunks by ID; cursor is the last one done, so a run
+-- cut short resumes after it.
+CREATE TABLE IF NOT EXISTS balance_refreshes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    wallet_ids UUID[],
+    chain_id INTEGER,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed')),
+    total INTEGER NOT NULL DEFAULT 0,
+    refreshed INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    cursor UUID,
+    last_error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_balance_refreshes_active ON balance_refreshes(created_at)
+    WHERE status IN ('pending', 'running');
+CREATE INDEX idx_balance_refreshes_created_at ON balance_refreshes(created_at DESC, id DESC);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_balance_refreshes_updated_at BEFORE UPDATE
+    ON balance_refreshes FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
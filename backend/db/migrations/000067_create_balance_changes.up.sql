@@ -0,0 +1,18 @@
+-- Create balance_changes table. A row is stored whenever a balance refresh finds a
+-- wallet's balance of a token differs from the stored one: a new token has a previous
+-- balance of 0, one no longer held a new balance of 0. delta_usd values the change at
+-- the token's current price, or at its last known one when the wallet no longer holds it.
+CREATE TABLE IF NOT EXISTS balance_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    token_id UUID NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    previous_balance DECIMAL(78, 0) NOT NULL,
+    new_balance DECIMAL(78, 0) NOT NULL,
+    delta DECIMAL(78, 0) NOT NULL,
+    delta_usd DECIMAL(30, 10),
+    block_number BIGINT,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_balance_changes_wallet_detected ON balance_changes(wallet_id, detected_at DESC);
+CREATE INDEX idx_balance_changes_token_id ON balance_changes(token_id);
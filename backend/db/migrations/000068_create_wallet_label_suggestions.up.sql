@@ -0,0 +1,17 @@
+-- Create wallet_label_suggestions table. While a wallet's history is imported, the
+-- transactions suggesting a label (such as "Uniswap LP" for dealing with Uniswap's
+-- position manager) are counted here. Labels with enough interactions are suggested to
+-- the owner, who can accept or dismiss them.
+CREATE TABLE IF NOT EXISTS wallet_label_suggestions (
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    label VARCHAR(50) NOT NULL,
+    interactions INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'suggested' CHECK (status IN ('suggested', 'accepted', 'dismissed')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wallet_id, label)
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_wallet_label_suggestions_updated_at BEFORE UPDATE
+    ON wallet_label_suggestions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
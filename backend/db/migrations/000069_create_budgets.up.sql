@@ -0,0 +1,16 @@
+-- Create budgets table of users' soft monthly spending limits. Spending against a budget
+-- is worked out from the analytics tables for the calendar month (UTC); going over it
+-- only fires the owner's budget_exceeded alerts.
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    metric VARCHAR(30) NOT NULL CHECK (metric IN ('gas_spend', 'trading_volume', 'realized_loss')),
+    limit_usd DECIMAL(30, 10) NOT NULL CHECK (limit_usd > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, metric)
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_budgets_updated_at BEFORE UPDATE
+    ON budgets FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
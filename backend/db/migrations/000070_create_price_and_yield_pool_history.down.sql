@@ -0,0 +1,3 @@
+-- Drop price and yield pool history tables
+DROP TABLE IF EXISTS yield_pool_history;
+DROP TABLE IF EXISTS price_history;
@@ -0,0 +1,21 @@
+-- Create price_history table the price refresh job snapshots token prices into
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    token_id UUID NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    price_usd DECIMAL(30, 10) NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    source VARCHAR(50) NOT NULL DEFAULT 'coingecko'
+);
+
+-- Create yield_pool_history table holding the APY and TVL of each pool at every sync
+CREATE TABLE IF NOT EXISTS yield_pool_history (
+    id BIGSERIAL PRIMARY KEY,
+    pool_id VARCHAR(255) NOT NULL REFERENCES yield_pools(pool_id) ON DELETE CASCADE,
+    apy DECIMAL(20, 10),
+    tvl_usd DECIMAL(30, 2),
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_price_history_token_timestamp ON price_history(token_id, timestamp DESC);
+CREATE INDEX idx_yield_pool_history_pool_recorded ON yield_pool_history(pool_id, recorded_at DESC);
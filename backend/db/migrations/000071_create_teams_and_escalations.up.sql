@@ -0,0 +1,82 @@
+-- Create teams table. Teams share alerting: an alert owned by a member can escalate
+-- through one of its team's policies to the other members.
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_teams_updated_at BEFORE UPDATE
+    ON teams FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Create team_members table. Owners manage members, policies and the on-call schedule.
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX idx_team_members_user ON team_members(user_id);
+
+-- Create escalation_policies table. steps is an ordered array of
+-- {delay_minutes, channel, user_id | on_call}; each step's delay counts from the one before.
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_escalation_policies_updated_at BEFORE UPDATE
+    ON escalation_policies FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE INDEX idx_escalation_policies_team ON escalation_policies(team_id);
+
+-- Create on_call_shifts table: who on the team is on call in [starts_at, ends_at)
+CREATE TABLE IF NOT EXISTS on_call_shifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_on_call_shifts_team_time ON on_call_shifts(team_id, starts_at, ends_at);
+
+-- Alerts escalating through a policy instead of notifying only their owner
+ALTER TABLE alerts ADD COLUMN escalation_policy_id UUID REFERENCES escalation_policies(id) ON DELETE SET NULL;
+
+-- Create alert_escalations table: one per trigger of an alert with a policy, walked
+-- step by step until a member acknowledges it or the steps run out
+CREATE TABLE IF NOT EXISTS alert_escalations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    history_id UUID NOT NULL UNIQUE REFERENCES alert_history(id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL REFERENCES escalation_policies(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'exhausted')),
+    next_step INT NOT NULL DEFAULT 0,
+    next_step_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    acknowledged_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    acknowledged_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_alert_escalations_updated_at BEFORE UPDATE
+    ON alert_escalations FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE INDEX idx_alert_escalations_due ON alert_escalations(next_step_at) WHERE status = 'open';
+CREATE INDEX idx_alert_escalations_team ON alert_escalations(team_id, created_at DESC);
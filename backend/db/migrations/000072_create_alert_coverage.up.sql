@@ -0,0 +1,18 @@
+-- Create alert_coverage table recording, per alert and hour, how often the evaluator
+-- actually looked at the alert and how many of those looks were on stale data or
+-- failed. Hours without a row are hours the alert wasn't being watched.
+CREATE TABLE IF NOT EXISTS alert_coverage (
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    bucket TIMESTAMPTZ NOT NULL, -- start of the hour, UTC
+    checks INT NOT NULL DEFAULT 0,
+    stale_checks INT NOT NULL DEFAULT 0,
+    failed_checks INT NOT NULL DEFAULT 0,
+    last_checked_at TIMESTAMPTZ NOT NULL,
+    -- When the newest data the evaluator had for the alert was from, for alerts whose
+    -- data carries a timestamp
+    last_data_at TIMESTAMPTZ,
+    PRIMARY KEY (alert_id, bucket)
+);
+
+-- Create index for pruning old buckets
+CREATE INDEX idx_alert_coverage_bucket ON alert_coverage(bucket);
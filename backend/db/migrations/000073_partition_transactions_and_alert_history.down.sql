@@ -0,0 +1,125 @@
+-- alert_history back to a single table
+ALTER TABLE alert_history RENAME TO alert_history_partitioned;
+ALTER INDEX alert_history_pkey RENAME TO alert_history_partitioned_pkey;
+ALTER INDEX idx_alert_history_alert_id RENAME TO idx_alert_history_partitioned_alert_id;
+ALTER INDEX idx_alert_history_triggered_at RENAME TO idx_alert_history_partitioned_triggered_at;
+
+CREATE TABLE alert_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    triggered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    conditions_snapshot JSONB,
+    triggered_value JSONB, -- The actual value that triggered the alert
+    notification_sent BOOLEAN DEFAULT FALSE,
+    notification_error TEXT
+);
+
+INSERT INTO alert_history (
+    id, alert_id, triggered_at, conditions_snapshot, triggered_value, notification_sent, notification_error
+)
+SELECT id, alert_id, triggered_at, conditions_snapshot, triggered_value, notification_sent, notification_error
+FROM alert_history_partitioned;
+
+DROP TABLE alert_history_partitioned;
+
+CREATE INDEX idx_alert_history_alert_id ON alert_history(alert_id);
+CREATE INDEX idx_alert_history_triggered_at ON alert_history(triggered_at DESC);
+
+ALTER TABLE notification_queue ADD CONSTRAINT notification_queue_history_id_fkey
+    FOREIGN KEY (history_id) REFERENCES alert_history(id) ON DELETE CASCADE;
+ALTER TABLE alert_deliveries ADD CONSTRAINT alert_deliveries_history_id_fkey
+    FOREIGN KEY (history_id) REFERENCES alert_history(id) ON DELETE CASCADE;
+ALTER TABLE notification_outbox ADD CONSTRAINT notification_outbox_history_id_fkey
+    FOREIGN KEY (history_id) REFERENCES alert_history(id) ON DELETE CASCADE;
+ALTER TABLE alert_escalations ADD CONSTRAINT alert_escalations_history_id_fkey
+    FOREIGN KEY (history_id) REFERENCES alert_history(id) ON DELETE CASCADE;
+
+-- transactions back to a single table; this fails if a hash is stored on several chains
+ALTER TABLE user_transactions DROP CONSTRAINT user_transactions_transaction_id_fkey;
+ALTER TABLE pnl_lots DROP CONSTRAINT pnl_lots_transaction_hash_fkey;
+
+DROP INDEX idx_transactions_from_address;
+DROP INDEX idx_transactions_to_address;
+DROP INDEX idx_transactions_timestamp;
+DROP INDEX idx_transactions_type;
+DROP INDEX idx_transactions_status;
+DROP INDEX idx_transactions_method_search;
+DROP INDEX idx_transactions_timestamp_id;
+DROP INDEX idx_transactions_chain_timestamp;
+DROP INDEX idx_transactions_from_address_lower;
+DROP INDEX idx_transactions_to_address_lower;
+DROP INDEX idx_transactions_token_address;
+DROP INDEX idx_transactions_chain_block;
+DROP INDEX idx_transactions_pending;
+DROP INDEX idx_transactions_manual;
+
+ALTER TABLE transactions RENAME TO transactions_partitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_partitioned_pkey;
+
+CREATE TABLE transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    hash VARCHAR(66) UNIQUE NOT NULL,
+    chain_id INTEGER NOT NULL,
+    from_address VARCHAR(42) NOT NULL,
+    to_address VARCHAR(42),
+    value DECIMAL(78, 0),
+    gas_used BIGINT,
+    gas_price DECIMAL(30, 0),
+    gas_fee_usd DECIMAL(30, 10),
+    block_number BIGINT,
+    timestamp TIMESTAMPTZ NOT NULL,
+    status transaction_status NOT NULL DEFAULT 'pending',
+    type transaction_type NOT NULL,
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    method_search tsvector GENERATED ALWAYS AS (
+        to_tsvector('simple', regexp_replace(coalesce(metadata->>'functionName', ''), '([a-z0-9])([A-Z])|[^A-Za-z0-9]+', '\1 \2', 'g'))
+    ) STORED,
+    block_hash VARCHAR(66),
+    confirmations INTEGER NOT NULL DEFAULT 0,
+    status_updated_at TIMESTAMPTZ,
+    source VARCHAR(20) NOT NULL DEFAULT 'onchain' CHECK (source IN ('onchain', 'manual'))
+);
+
+INSERT INTO transactions (
+    id, hash, chain_id, from_address, to_address, value, gas_used, gas_price, gas_fee_usd,
+    block_number, timestamp, status, type, metadata, created_at, updated_at,
+    block_hash, confirmations, status_updated_at, source
+)
+SELECT id, hash, chain_id, from_address, to_address, value, gas_used, gas_price, gas_fee_usd,
+       block_number, timestamp, status, type, metadata, created_at, updated_at,
+       block_hash, confirmations, status_updated_at, source
+FROM transactions_partitioned;
+
+DROP TABLE transactions_partitioned;
+
+CREATE INDEX idx_transactions_hash ON transactions(hash);
+CREATE INDEX idx_transactions_from_address ON transactions(from_address);
+CREATE INDEX idx_transactions_to_address ON transactions(to_address);
+CREATE INDEX idx_transactions_timestamp ON transactions(timestamp DESC);
+CREATE INDEX idx_transactions_chain_id ON transactions(chain_id);
+CREATE INDEX idx_transactions_type ON transactions(type);
+CREATE INDEX idx_transactions_status ON transactions(status);
+CREATE INDEX idx_transactions_method_search ON transactions USING GIN(method_search);
+CREATE INDEX idx_transactions_timestamp_id ON transactions(timestamp DESC, id DESC);
+CREATE INDEX idx_transactions_chain_timestamp ON transactions(chain_id, timestamp DESC);
+CREATE INDEX idx_transactions_from_address_lower ON transactions(lower(from_address));
+CREATE INDEX idx_transactions_to_address_lower ON transactions(lower(to_address));
+CREATE INDEX idx_transactions_token_address ON transactions(lower(metadata->>'token_address'));
+CREATE INDEX idx_transactions_chain_block ON transactions(chain_id, block_number) WHERE block_hash IS NOT NULL;
+CREATE INDEX idx_transactions_pending ON transactions(created_at) WHERE status = 'pending';
+CREATE INDEX idx_transactions_manual ON transactions(source) WHERE source = 'manual';
+
+CREATE TRIGGER update_transactions_updated_at BEFORE UPDATE
+    ON transactions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE user_transactions DROP COLUMN chain_id;
+ALTER TABLE user_transactions ADD CONSTRAINT user_transactions_transaction_id_fkey
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE;
+ALTER TABLE pnl_lots ADD CONSTRAINT pnl_lots_transaction_hash_fkey
+    FOREIGN KEY (transaction_hash) REFERENCES transactions(hash) ON DELETE CASCADE;
+ALTER TABLE income_overrides ADD CONSTRAINT income_overrides_transaction_hash_fkey
+    FOREIGN KEY (transaction_hash) REFERENCES transactions(hash) ON DELETE CASCADE;
+
+DROP FUNCTION IF EXISTS ensure_monthly_partitions(TEXT, TIMESTAMPTZ, TIMESTAMPTZ);
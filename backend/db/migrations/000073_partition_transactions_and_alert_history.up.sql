@@ -0,0 +1,163 @@
+-- Partition the high-volume tables so each partition's indexes stay small and can be
+-- vacuumed on its own: transactions by chain and alert_history by month. A partitioned
+-- table's primary and unique keys must include the partition key, so transactions are
+-- now unique per (id, chain_id) and (hash, chain_id) and alert_history per
+-- (id, triggered_at).
+
+-- ensure_monthly_partitions creates the missing monthly partitions of a table partitioned
+-- by range on a timestamp, for every UTC month overlapping [from_time, to_time]. Months
+-- whose rows already landed in the default partition are skipped with a warning, as the
+-- partition can't be created until they're moved. Returns how many were created.
+CREATE OR REPLACE FUNCTION ensure_monthly_partitions(parent TEXT, from_time TIMESTAMPTZ, to_time TIMESTAMPTZ)
+RETURNS INTEGER AS $$
+DECLARE
+    -- Month arithmetic is done on UTC wall time so the session's timezone can't shift it
+    month_start TIMESTAMP := date_trunc('month', from_time AT TIME ZONE 'UTC');
+    partition_name TEXT;
+    created INTEGER := 0;
+BEGIN
+    WHILE month_start <= to_time AT TIME ZONE 'UTC' LOOP
+        partition_name := parent || '_' || to_char(month_start, 'YYYY_MM');
+        IF to_regclass(partition_name) IS NULL THEN
+            BEGIN
+                EXECUTE format('CREATE TABLE %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+                    partition_name, parent,
+                    month_start AT TIME ZONE 'UTC', (month_start + INTERVAL '1 month') AT TIME ZONE 'UTC');
+                created := created + 1;
+            EXCEPTION WHEN check_violation THEN
+                RAISE WARNING 'rows for % are in the default partition of %; partition not created', partition_name, parent;
+            END;
+        END IF;
+        month_start := month_start + INTERVAL '1 month';
+    END LOOP;
+    RETURN created;
+END;
+$$ LANGUAGE plpgsql;
+
+-- transactions: keys referencing it now carry the chain
+ALTER TABLE user_transactions DROP CONSTRAINT user_transactions_transaction_id_fkey;
+ALTER TABLE pnl_lots DROP CONSTRAINT pnl_lots_transaction_hash_fkey;
+-- Income overrides are keyed by hash like transaction annotations, so a user's
+-- classification survives the transaction being removed by a reorg and re-synced
+ALTER TABLE income_overrides DROP CONSTRAINT income_overrides_transaction_hash_fkey;
+
+ALTER TABLE user_transactions ADD COLUMN chain_id INTEGER;
+UPDATE user_transactions ut SET chain_id = t.chain_id FROM transactions t WHERE t.id = ut.transaction_id;
+ALTER TABLE user_transactions ALTER COLUMN chain_id SET NOT NULL;
+
+ALTER TABLE transactions RENAME TO transactions_unpartitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_unpartitioned_pkey;
+ALTER INDEX transactions_hash_key RENAME TO transactions_unpartitioned_hash_key;
+
+CREATE TABLE transactions (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    hash VARCHAR(66) NOT NULL,
+    chain_id INTEGER NOT NULL,
+    from_address VARCHAR(42) NOT NULL,
+    to_address VARCHAR(42),
+    value DECIMAL(78, 0),
+    gas_used BIGINT,
+    gas_price DECIMAL(30, 0),
+    gas_fee_usd DECIMAL(30, 10),
+    block_number BIGINT,
+    timestamp TIMESTAMPTZ NOT NULL,
+    status transaction_status NOT NULL DEFAULT 'pending',
+    type transaction_type NOT NULL,
+    metadata JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    method_search tsvector GENERATED ALWAYS AS (
+        to_tsvector('simple', regexp_replace(coalesce(metadata->>'functionName', ''), '([a-z0-9])([A-Z])|[^A-Za-z0-9]+', '\1 \2', 'g'))
+    ) STORED,
+    block_hash VARCHAR(66),
+    confirmations INTEGER NOT NULL DEFAULT 0,
+    status_updated_at TIMESTAMPTZ,
+    source VARCHAR(20) NOT NULL DEFAULT 'onchain' CHECK (source IN ('onchain', 'manual')),
+    PRIMARY KEY (id, chain_id),
+    UNIQUE (hash, chain_id)
+) PARTITION BY LIST (chain_id);
+
+-- One partition per built-in chain; custom chains share the default partition
+CREATE TABLE transactions_ethereum PARTITION OF transactions FOR VALUES IN (1);
+CREATE TABLE transactions_optimism PARTITION OF transactions FOR VALUES IN (10);
+CREATE TABLE transactions_polygon PARTITION OF transactions FOR VALUES IN (137);
+CREATE TABLE transactions_arbitrum PARTITION OF transactions FOR VALUES IN (42161);
+CREATE TABLE transactions_polygon_amoy PARTITION OF transactions FOR VALUES IN (80002);
+CREATE TABLE transactions_default PARTITION OF transactions DEFAULT;
+
+INSERT INTO transactions (
+    id, hash, chain_id, from_address, to_address, value, gas_used, gas_price, gas_fee_usd,
+    block_number, timestamp, status, type, metadata, created_at, updated_at,
+    block_hash, confirmations, status_updated_at, source
+)
+SELECT id, hash, chain_id, from_address, to_address, value, gas_used, gas_price, gas_fee_usd,
+       block_number, timestamp, status, type, metadata, created_at, updated_at,
+       block_hash, confirmations, status_updated_at, source
+FROM transactions_unpartitioned;
+
+DROP TABLE transactions_unpartitioned;
+
+-- Recreate indexes; lookups by hash use the (hash, chain_id) key
+CREATE INDEX idx_transactions_from_address ON transactions(from_address);
+CREATE INDEX idx_transactions_to_address ON transactions(to_address);
+CREATE INDEX idx_transactions_timestamp ON transactions(timestamp DESC);
+CREATE INDEX idx_transactions_type ON transactions(type);
+CREATE INDEX idx_transactions_status ON transactions(status);
+CREATE INDEX idx_transactions_method_search ON transactions USING GIN(method_search);
+CREATE INDEX idx_transactions_timestamp_id ON transactions(timestamp DESC, id DESC);
+CREATE INDEX idx_transactions_chain_timestamp ON transactions(chain_id, timestamp DESC);
+CREATE INDEX idx_transactions_from_address_lower ON transactions(lower(from_address));
+CREATE INDEX idx_transactions_to_address_lower ON transactions(lower(to_address));
+CREATE INDEX idx_transactions_token_address ON transactions(lower(metadata->>'token_address'));
+CREATE INDEX idx_transactions_chain_block ON transactions(chain_id, block_number) WHERE block_hash IS NOT NULL;
+CREATE INDEX idx_transactions_pending ON transactions(created_at) WHERE status = 'pending';
+CREATE INDEX idx_transactions_manual ON transactions(source) WHERE source = 'manual';
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_transactions_updated_at BEFORE UPDATE
+    ON transactions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE user_transactions ADD CONSTRAINT user_transactions_transaction_id_fkey
+    FOREIGN KEY (transaction_id, chain_id) REFERENCES transactions(id, chain_id) ON DELETE CASCADE;
+ALTER TABLE pnl_lots ADD CONSTRAINT pnl_lots_transaction_hash_fkey
+    FOREIGN KEY (transaction_hash, chain_id) REFERENCES transactions(hash, chain_id) ON DELETE CASCADE;
+
+-- alert_history: rows referencing a trigger are removed with their alert instead, as
+-- they don't carry the trigger time a key on the partitioned table would need
+ALTER TABLE notification_queue DROP CONSTRAINT notification_queue_history_id_fkey;
+ALTER TABLE alert_deliveries DROP CONSTRAINT alert_deliveries_history_id_fkey;
+ALTER TABLE notification_outbox DROP CONSTRAINT notification_outbox_history_id_fkey;
+ALTER TABLE alert_escalations DROP CONSTRAINT alert_escalations_history_id_fkey;
+
+ALTER TABLE alert_history RENAME TO alert_history_unpartitioned;
+ALTER INDEX alert_history_pkey RENAME TO alert_history_unpartitioned_pkey;
+
+CREATE TABLE alert_history (
+    id UUID NOT NULL DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES alerts(id) ON DELETE CASCADE,
+    triggered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    conditions_snapshot JSONB,
+    triggered_value JSONB, -- The actual value that triggered the alert
+    notification_sent BOOLEAN DEFAULT FALSE,
+    notification_error TEXT,
+    PRIMARY KEY (id, triggered_at)
+) PARTITION BY RANGE (triggered_at);
+
+-- Monthly partitions from the oldest trigger to three months out; the worker keeps
+-- creating them ahead of time, and the default partition catches anything outside
+CREATE TABLE alert_history_default PARTITION OF alert_history DEFAULT;
+SELECT ensure_monthly_partitions('alert_history',
+    COALESCE((SELECT MIN(triggered_at) FROM alert_history_unpartitioned), NOW()),
+    NOW() + INTERVAL '3 months');
+
+INSERT INTO alert_history (
+    id, alert_id, triggered_at, conditions_snapshot, triggered_value, notification_sent, notification_error
+)
+SELECT id, alert_id, triggered_at, conditions_snapshot, triggered_value, notification_sent, notification_error
+FROM alert_history_unpartitioned;
+
+DROP TABLE alert_history_unpartitioned;
+
+-- Recreate indexes
+CREATE INDEX idx_alert_history_alert_id ON alert_history(alert_id, triggered_at DESC);
+CREATE INDEX idx_alert_history_triggered_at ON alert_history(triggered_at DESC);
@@ -0,0 +1,10 @@
+UPDATE tokens t
+SET price_usd = p.price_usd, price_change_24h = p.price_change_24h, last_updated = p.updated_at
+FROM token_prices_latest p
+WHERE p.token_id = t.id;
+
+COMMENT ON COLUMN tokens.price_usd IS NULL;
+COMMENT ON COLUMN tokens.price_change_24h IS NULL;
+COMMENT ON COLUMN tokens.last_updated IS NULL;
+
+DROP TABLE IF EXISTS token_prices_latest;
@@ -0,0 +1,22 @@
+-- Create token_prices_latest table holding each token's live price. Refreshing prices in
+-- place rewrote the wide tokens rows every few minutes, bloating the table and its
+-- indexes and contending for row locks with metadata and logo upserts. These rows are
+-- narrow with only the key indexed, and the fill factor leaves room for updates to stay
+-- on the same page.
+CREATE TABLE IF NOT EXISTS token_prices_latest (
+    token_id UUID PRIMARY KEY REFERENCES tokens(id) ON DELETE CASCADE,
+    price_usd DECIMAL(30, 10) NOT NULL,
+    price_change_24h DECIMAL(10, 4),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+) WITH (fillfactor = 70);
+
+INSERT INTO token_prices_latest (token_id, price_usd, price_change_24h, updated_at)
+SELECT id, price_usd, price_change_24h, COALESCE(last_updated, updated_at)
+FROM tokens
+WHERE price_usd IS NOT NULL;
+
+-- The old price columns are no longer written, but stay until no running release reads
+-- them so the switch needs no downtime; a later migration drops them
+COMMENT ON COLUMN tokens.price_usd IS 'Deprecated: live prices are in token_prices_latest';
+COMMENT ON COLUMN tokens.price_change_24h IS 'Deprecated: live prices are in token_prices_latest';
+COMMENT ON COLUMN tokens.last_updated IS 'Deprecated: live prices are in token_prices_latest';
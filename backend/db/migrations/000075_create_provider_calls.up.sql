@@ -0,0 +1,23 @@
+-- Create provider_calls table logging calls to upstream providers, so a support ticket
+-- about a wrong quote or balance can be traced from the API request ID to the exact
+-- provider responses behind it. Responses themselves aren't kept, only a hash of each.
+CREATE TABLE IF NOT EXISTS provider_calls (
+    id BIGSERIAL PRIMARY KEY,
+    request_id VARCHAR(128), -- NULL for calls made by scheduled jobs
+    provider VARCHAR(32) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    endpoint TEXT NOT NULL, -- path and query, with API keys redacted
+    status INT, -- NULL when no response came back
+    duration_ms INT NOT NULL,
+    response_hash CHAR(64), -- SHA-256 of the first megabyte of the response read
+    response_bytes BIGINT,
+    error TEXT,
+    source VARCHAR(10) NOT NULL CHECK (source IN ('api', 'worker')),
+    started_at TIMESTAMPTZ NOT NULL
+);
+
+-- Create indexes for lookups by request and provider, and for pruning
+CREATE INDEX idx_provider_calls_request_id ON provider_calls(request_id) WHERE request_id IS NOT NULL;
+CREATE INDEX idx_provider_calls_provider_started_at ON provider_calls(provider, started_at DESC);
+CREATE INDEX idx_provider_calls_started_at ON provider_calls(started_at);
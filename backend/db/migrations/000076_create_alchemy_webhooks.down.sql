@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS alchemy_webhook_addresses;
+DROP TABLE IF EXISTS alchemy_webhook_events;
@@ -0,0 +1,35 @@
+-- Create alchemy_webhook_events table queuing the Alchemy Notify deliveries received,
+-- so the endpoint can answer at once and the worker ingests the activity behind them.
+-- Alchemy retries deliveries, so each event is kept once by its ID.
+CREATE TABLE IF NOT EXISTS alchemy_webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id VARCHAR(128) NOT NULL UNIQUE,
+    webhook_id VARCHAR(128) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    chain_id INTEGER NOT NULL,
+    -- Senders and recipients involved, lowercased
+    addresses TEXT[] NOT NULL,
+    -- Blocks the activity was mined in; NULL for activity seen before it was mined
+    from_block BIGINT,
+    to_block BIGINT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'failed')),
+    -- Failed processing attempts; the event fails after too many
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_alchemy_webhook_events_pending ON alchemy_webhook_events(received_at)
+    WHERE status = 'pending';
+CREATE INDEX idx_alchemy_webhook_events_received_at ON alchemy_webhook_events(received_at);
+
+-- Create alchemy_webhook_addresses table recording the addresses each webhook has been
+-- told to watch, so only the difference is sent when wallets are added or removed
+CREATE TABLE IF NOT EXISTS alchemy_webhook_addresses (
+    webhook_id VARCHAR(128) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    chain_id INTEGER NOT NULL,
+    subscribed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (webhook_id, address)
+);
@@ -0,0 +1,25 @@
+-- Create team_pending_actions table. In teams with more than one owner, destructive
+-- changes (removing a member, changing or deleting an escalation policy) wait here until
+-- another owner approves or rejects them, or they expire. payload holds what's needed
+-- to apply the change, such as a policy's new steps.
+CREATE TABLE IF NOT EXISTS team_pending_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    action VARCHAR(40) NOT NULL,
+    target_id UUID NOT NULL,
+    payload JSONB,
+    requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'rejected', 'expired', 'failed')),
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMPTZ,
+    error TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_team_pending_actions_team ON team_pending_actions(team_id, created_at DESC);
+
+-- A change can only be awaiting approval once
+CREATE UNIQUE INDEX idx_team_pending_actions_open ON team_pending_actions(team_id, action, target_id)
+    WHERE status = 'pending';
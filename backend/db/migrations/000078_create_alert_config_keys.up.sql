@@ -0,0 +1,11 @@
+-- Create alert_config_keys table. Alerts managed through PUT /alerts/config are named
+-- by a key unique to their owner, which is how a declarative config is matched against
+-- existing alerts. Alerts created any other way have no key and the config leaves
+-- them alone.
+CREATE TABLE IF NOT EXISTS alert_config_keys (
+    alert_id UUID PRIMARY KEY REFERENCES alerts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT alert_config_keys_user_key UNIQUE (user_id, key)
+);
@@ -0,0 +1,11 @@
+-- Create job_runs table recording each worker job's latest run, so the status endpoint
+-- can tell a job that stopped running or keeps failing. One row per job, overwritten on
+-- every run.
+CREATE TABLE IF NOT EXISTS job_runs (
+    job_name VARCHAR(100) PRIMARY KEY,
+    last_started_at TIMESTAMPTZ NOT NULL,
+    last_finished_at TIMESTAMPTZ NOT NULL,
+    last_success_at TIMESTAMPTZ,
+    last_error TEXT,
+    last_duration_ms INT NOT NULL DEFAULT 0
+);
@@ -0,0 +1 @@
+ALTER TABLE token_allowances DROP COLUMN IF EXISTS verified_at;
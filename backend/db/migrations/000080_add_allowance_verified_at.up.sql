@@ -0,0 +1,3 @@
+-- Record when each stored allowance was last checked on chain, so clients can tell a
+-- fresh row from one that may predate a revoke
+ALTER TABLE token_allowances ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
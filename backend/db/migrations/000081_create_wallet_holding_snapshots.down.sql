@@ -0,0 +1,3 @@
+-- Drop wallet holding snapshot tables
+DROP TABLE IF EXISTS wallet_holding_snapshots;
+DROP TABLE IF EXISTS holding_snapshot_runs;
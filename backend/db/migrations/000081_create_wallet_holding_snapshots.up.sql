@@ -0,0 +1,21 @@
+-- Create wallet_holding_snapshots table holding periodic per-token balances of each
+-- mainnet wallet, for diffing a portfolio against an earlier point in time.
+-- Each snapshot run is recorded in holding_snapshot_runs, so a wallet without rows at a
+-- run held nothing then.
+CREATE TABLE IF NOT EXISTS holding_snapshot_runs (
+    recorded_at TIMESTAMPTZ PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS wallet_holding_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    token_id UUID NOT NULL REFERENCES tokens(id) ON DELETE CASCADE,
+    balance DECIMAL(78, 0) NOT NULL,
+    price_usd DECIMAL(30, 10),
+    value_usd DECIMAL(30, 10) NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL REFERENCES holding_snapshot_runs(recorded_at) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX idx_wallet_holding_snapshots_wallet_recorded ON wallet_holding_snapshots(wallet_id, recorded_at DESC);
+CREATE INDEX idx_wallet_holding_snapshots_recorded_at ON wallet_holding_snapshots(recorded_at);
@@ -0,0 +1,8 @@
+-- Create onboarding_steps table recording when each user completed each step of the
+-- guided setup. Steps are only ever added; a step's row is its completion.
+CREATE TABLE IF NOT EXISTS onboarding_steps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    step VARCHAR(50) NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, step)
+);
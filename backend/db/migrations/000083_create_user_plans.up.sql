@@ -0,0 +1,10 @@
+-- Create user_plans table holding each user's plan tier. Users without a row are on the
+-- free plan. Admins can override any of the tier's limits for a user.
+CREATE TABLE IF NOT EXISTS user_plans (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    tier VARCHAR(20) NOT NULL DEFAULT 'free' CHECK (tier IN ('free', 'pro')),
+    limit_overrides JSONB NOT NULL DEFAULT '{}',
+    note TEXT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
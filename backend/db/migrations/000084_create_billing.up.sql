@@ -0,0 +1,41 @@
+-- Create billing tables. billing_accounts ties a user to their Stripe customer and
+-- subscription, as last reported by Stripe's webhooks; grace_until is set when a renewal
+-- payment fails and is how long the user keeps the pro tier while it's retried.
+CREATE TABLE IF NOT EXISTS billing_accounts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    stripe_customer_id VARCHAR(255) NOT NULL UNIQUE,
+    stripe_subscription_id VARCHAR(255),
+    status VARCHAR(30) NOT NULL DEFAULT 'incomplete',
+    current_period_end TIMESTAMPTZ,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    grace_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_billing_accounts_grace_until ON billing_accounts(grace_until) WHERE grace_until IS NOT NULL;
+
+-- Invoices as Stripe reports them, for users to list
+CREATE TABLE IF NOT EXISTS billing_invoices (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    number VARCHAR(100),
+    status VARCHAR(30) NOT NULL,
+    amount_due BIGINT NOT NULL DEFAULT 0,
+    amount_paid BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(10) NOT NULL,
+    hosted_invoice_url TEXT,
+    invoice_pdf TEXT,
+    period_start TIMESTAMPTZ,
+    period_end TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_billing_invoices_user_created ON billing_invoices(user_id, created_at DESC);
+
+-- Stripe events already handled; Stripe redelivers events until they're acknowledged
+CREATE TABLE IF NOT EXISTS billing_events (
+    id VARCHAR(255) PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
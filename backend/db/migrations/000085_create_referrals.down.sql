@@ -0,0 +1,3 @@
+ALTER TABLE user_plans DROP COLUMN IF EXISTS trial_ends_at;
+DROP TABLE IF EXISTS referrals;
+DROP TABLE IF EXISTS referral_codes;
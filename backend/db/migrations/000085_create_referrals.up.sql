@@ -0,0 +1,25 @@
+-- Create referral tables. Each user gets one code to share; referrals records who signed
+-- up with whose code, whether it was rewarded or rejected as a self-referral, and the
+-- pro trial days each side was granted.
+CREATE TABLE IF NOT EXISTS referral_codes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    referrer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    referred_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(16) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('rewarded', 'rejected')),
+    reject_reason VARCHAR(50),
+    referrer_reward_days INTEGER NOT NULL DEFAULT 0,
+    referred_reward_days INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_referrals_referrer ON referrals(referrer_id, created_at DESC);
+
+-- Users on a pro trial get pro limits until it ends, whatever their tier
+ALTER TABLE user_plans ADD COLUMN IF NOT EXISTS trial_ends_at TIMESTAMPTZ;
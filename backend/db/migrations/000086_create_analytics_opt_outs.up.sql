@@ -0,0 +1,6 @@
+-- Create analytics_opt_outs table holding the users who turned off usage analytics. No
+-- events are sent for them.
+CREATE TABLE IF NOT EXISTS analytics_opt_outs (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    opted_out_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS experiment_conversions;
+DROP TABLE IF EXISTS experiment_exposures;
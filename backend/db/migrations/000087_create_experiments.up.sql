@@ -0,0 +1,21 @@
+-- Create experiment tables. An experiment is a feature flag with variants; exposures
+-- records the variant each user was first shown, and conversions the first time an
+-- exposed user then did each tracked action.
+CREATE TABLE IF NOT EXISTS experiment_exposures (
+    experiment VARCHAR(100) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    variant VARCHAR(50) NOT NULL,
+    exposed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (experiment, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiment_exposures_user ON experiment_exposures(user_id);
+
+CREATE TABLE IF NOT EXISTS experiment_conversions (
+    experiment VARCHAR(100) NOT NULL,
+    user_id UUID NOT NULL,
+    metric VARCHAR(100) NOT NULL,
+    converted_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (experiment, user_id, metric),
+    FOREIGN KEY (experiment, user_id) REFERENCES experiment_exposures(experiment, user_id) ON DELETE CASCADE
+);
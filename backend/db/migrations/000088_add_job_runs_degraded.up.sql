@@ -0,0 +1,4 @@
+-- Record why a job's latest run, though it succeeded, left work undone, such as alerts
+-- skipped because the prices they read were stale. The status page reports such a job
+-- as degraded.
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS last_degraded TEXT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS wallet_removals;
+DROP INDEX IF EXISTS idx_wallets_removed;
+ALTER TABLE wallets DROP COLUMN IF EXISTS removed_at;
@@ -0,0 +1,35 @@
+-- A removed wallet disappears from its owner's views at once; the wallet_removals job
+-- then clears its data in steps. Wallets removed keeping their history stay behind,
+-- removed, to hold their transactions and PnL lots; purged ones are deleted at the end.
+ALTER TABLE wallets ADD COLUMN removed_at TIMESTAMPTZ;
+
+CREATE INDEX idx_wallets_removed ON wallets(removed_at) WHERE removed_at IS NOT NULL;
+
+-- Create wallet_removals table. wallet_id has no foreign key: a purge deletes the
+-- wallet while its removal is kept to report on.
+CREATE TABLE IF NOT EXISTS wallet_removals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    address VARCHAR(128) NOT NULL,
+    chain_id INTEGER NOT NULL,
+    retention VARCHAR(20) NOT NULL CHECK (retention IN ('purge', 'keep_history')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    steps_total INTEGER NOT NULL,
+    steps_done INTEGER NOT NULL DEFAULT 0,
+    rows_deleted BIGINT NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_wallet_removals_active ON wallet_removals(created_at)
+    WHERE status IN ('pending', 'running');
+CREATE INDEX idx_wallet_removals_wallet_id ON wallet_removals(wallet_id);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_wallet_removals_updated_at BEFORE UPDATE
+    ON wallet_removals FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
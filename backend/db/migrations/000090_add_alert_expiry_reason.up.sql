@@ -0,0 +1,4 @@
+-- Alerts whose pool or token disappeared are expired by the alert target job with the
+-- reason, rather than evaluated against missing data forever
+ALTER TABLE alerts ADD COLUMN expired_reason VARCHAR(32);
+ALTER TABLE alerts ADD COLUMN expired_at TIMESTAMPTZ;
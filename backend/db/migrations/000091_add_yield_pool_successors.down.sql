@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS yield_pool_migration_notices;
+DROP INDEX IF EXISTS idx_yield_positions_migrated_from;
+ALTER TABLE yield_positions DROP COLUMN IF EXISTS migrated_from_position_id;
+DROP INDEX IF EXISTS idx_yield_pools_successor;
+ALTER TABLE yield_pools
+    DROP CONSTRAINT IF EXISTS yield_pools_successor_not_self,
+    DROP COLUMN IF EXISTS deprecated_at,
+    DROP COLUMN IF EXISTS successor_pool_id;
@@ -0,0 +1,26 @@
+-- A pool a protocol migrated away from (v2 to v3, say) is deprecated and names the pool
+-- that replaced it
+ALTER TABLE yield_pools
+    ADD COLUMN successor_pool_id VARCHAR(255) REFERENCES yield_pools(pool_id) ON DELETE SET NULL,
+    ADD COLUMN deprecated_at TIMESTAMPTZ,
+    ADD CONSTRAINT yield_pools_successor_not_self CHECK (successor_pool_id <> pool_id);
+
+CREATE INDEX idx_yield_pools_successor ON yield_pools(successor_pool_id) WHERE successor_pool_id IS NOT NULL;
+
+-- A position opened in the successor carries on the one the wallet held in the old pool
+ALTER TABLE yield_positions
+    ADD COLUMN migrated_from_position_id UUID REFERENCES yield_positions(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_yield_positions_migrated_from ON yield_positions(migrated_from_position_id)
+    WHERE migrated_from_position_id IS NOT NULL;
+
+-- Create yield_pool_migration_notices table recording the holders told of a migration,
+-- so each position's owner hears of each mapping once
+CREATE TABLE IF NOT EXISTS yield_pool_migration_notices (
+    position_id UUID NOT NULL REFERENCES yield_positions(id) ON DELETE CASCADE,
+    successor_pool_id VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emailed BOOLEAN NOT NULL DEFAULT FALSE,
+    notified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (position_id, successor_pool_id)
+);
@@ -0,0 +1,15 @@
+-- Create token_holder_stats table with how concentrated each held token's supply is
+-- among its largest holders, from the chain's explorer
+CREATE TABLE IF NOT EXISTS token_holder_stats (
+    token_id UUID PRIMARY KEY REFERENCES tokens(id) ON DELETE CASCADE,
+    total_supply DECIMAL(78, 0),
+    -- The largest holders, largest first, as [{address, balance, share_pct}]
+    top_holders JSONB NOT NULL DEFAULT '[]',
+    top10_share_pct DECIMAL(7, 4),
+    source VARCHAR(50) NOT NULL,
+    -- Set when the explorer has no holder data so it isn't re-fetched every run
+    not_found BOOLEAN NOT NULL DEFAULT FALSE,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_token_holder_stats_fetched_at ON token_holder_stats(fetched_at);
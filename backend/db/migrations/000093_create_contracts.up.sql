@@ -0,0 +1,21 @@
+-- Create contracts table caching what the chain's explorer knows of the tokens and
+-- spenders users deal with
+CREATE TABLE IF NOT EXISTS contracts (
+    chain_id INTEGER NOT NULL,
+    address VARCHAR(42) NOT NULL,
+    -- FALSE for addresses with no code, such as spenders that are plain wallets
+    is_contract BOOLEAN NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    contract_name VARCHAR(255),
+    is_proxy BOOLEAN NOT NULL DEFAULT FALSE,
+    implementation_address VARCHAR(42),
+    implementation_verified BOOLEAN,
+    creator_address VARCHAR(42),
+    creation_tx_hash VARCHAR(66),
+    deployed_at TIMESTAMPTZ,
+    source VARCHAR(50) NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chain_id, address)
+);
+
+CREATE INDEX idx_contracts_fetched_at ON contracts(fetched_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS poisoned_counterparties;
+ALTER TABLE transactions DROP COLUMN IF EXISTS poisoning_lookalike_of;
@@ -0,0 +1,15 @@
+-- Flag transfers suspected of address poisoning: zero-value transfers with an address
+-- made to look like one the wallet really deals with. Analytics leave them out.
+ALTER TABLE transactions ADD COLUMN poisoning_lookalike_of VARCHAR(42);
+
+-- Create poisoned_counterparties table of the lookalike addresses seen per wallet, so
+-- the wallet's owner can be warned before copying one from their history
+CREATE TABLE IF NOT EXISTS poisoned_counterparties (
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL, -- stored lowercase
+    lookalike_of VARCHAR(42) NOT NULL,
+    transaction_count INTEGER NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    last_seen_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (wallet_id, address)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS wallet_exposures;
+DROP TABLE IF EXISTS screening_list_addresses;
+DROP TABLE IF EXISTS screening_lists;
@@ -0,0 +1,47 @@
+-- Create screening_lists table of the sanctions and mixer address lists counterparties
+-- are screened against when compliance screening is on
+CREATE TABLE IF NOT EXISTS screening_lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    category VARCHAR(20) NOT NULL CHECK (category IN ('sanctions', 'mixer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_screening_lists_updated_at BEFORE UPDATE
+    ON screening_lists FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS screening_list_addresses (
+    list_id UUID NOT NULL REFERENCES screening_lists(id) ON DELETE CASCADE,
+    address VARCHAR(42) NOT NULL, -- stored lowercase
+    PRIMARY KEY (list_id, address)
+);
+
+CREATE INDEX idx_screening_list_addresses_address ON screening_list_addresses(address);
+
+-- Create wallet_exposures table of the wallets the screening job found dealing with
+-- listed addresses; wallets without exposure have no row
+CREATE TABLE IF NOT EXISTS wallet_exposures (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets(id) ON DELETE CASCADE,
+    level VARCHAR(10) NOT NULL,
+    hits JSONB NOT NULL DEFAULT '[]',
+    screened_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_wallet_exposures_level ON wallet_exposures(level);
+
+-- The Tornado Cash router and ETH pools, as designated by OFAC in August 2022
+WITH tornado AS (
+    INSERT INTO screening_lists (name, category) VALUES ('Tornado Cash', 'mixer')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING id
+)
+INSERT INTO screening_list_addresses (list_id, address)
+SELECT tornado.id, a.address
+FROM tornado, (VALUES
+    ('0xd90e2f925da726b50c4ed8d0fb90ad053324f31b'),
+    ('0x12d66f87a04a9e220743712ce6d9bb1b5616b8fc'),
+    ('0x47ce0c6ed5b0ce3d3a51fdb1c52dc66a7c3c2936'),
+    ('0x910cbd523d972eb0a6f4cae4618ad62622b39dbf'),
+    ('0xa160cdab225685da1d56aa342ad8841c3b53f291')
+) AS a(address);
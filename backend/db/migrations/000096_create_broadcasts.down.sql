@@ -0,0 +1,3 @@
+ALTER TABLE system_banners DROP COLUMN IF EXISTS broadcast_id;
+DROP TABLE IF EXISTS broadcast_recipients;
+DROP TABLE IF EXISTS broadcasts;
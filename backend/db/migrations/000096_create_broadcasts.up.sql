@@ -0,0 +1,34 @@
+-- Create broadcasts table of the one-off messages admins sent to a segment of users,
+-- like the holders of a token or the users with positions in an exploited protocol
+CREATE TABLE IF NOT EXISTS broadcasts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255),
+    message TEXT NOT NULL,
+    level banner_level NOT NULL DEFAULT 'info',
+    audience JSONB NOT NULL,
+    banner BOOLEAN NOT NULL,
+    email BOOLEAN NOT NULL,
+    recipients INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_broadcasts_created_at ON broadcasts(created_at DESC);
+
+-- Create broadcast_recipients table of the users each broadcast reached. Their email is
+-- pending until the broadcast email job sends it.
+CREATE TABLE IF NOT EXISTS broadcast_recipients (
+    broadcast_id UUID NOT NULL REFERENCES broadcasts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email_pending BOOLEAN NOT NULL DEFAULT FALSE,
+    emailed_at TIMESTAMPTZ,
+    PRIMARY KEY (broadcast_id, user_id)
+);
+
+CREATE INDEX idx_broadcast_recipients_user_id ON broadcast_recipients(user_id);
+CREATE INDEX idx_broadcast_recipients_email_pending ON broadcast_recipients(broadcast_id) WHERE email_pending;
+
+-- A banner sent with a broadcast shows only to its recipients
+ALTER TABLE system_banners ADD COLUMN IF NOT EXISTS broadcast_id UUID REFERENCES broadcasts(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_system_banners_broadcast_id ON system_banners(broadcast_id) WHERE broadcast_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop custom_metrics table
+DROP TABLE IF EXISTS custom_metrics;
@@ -0,0 +1,23 @@
+-- Create custom_metrics table holding the numbers users define over their portfolio with
+-- an expression, shown as dashboard widgets. value, error and computed_at cache the last
+-- evaluation until it goes stale.
+CREATE TABLE IF NOT EXISTS custom_metrics (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    expression TEXT NOT NULL,
+    format VARCHAR(10) NOT NULL CHECK (format IN ('usd', 'percent', 'number')),
+    position INTEGER NOT NULL DEFAULT 0,
+    value DOUBLE PRECISION,
+    error TEXT,
+    computed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX idx_custom_metrics_user_id ON custom_metrics(user_id, position);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_custom_metrics_updated_at BEFORE UPDATE
+    ON custom_metrics FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
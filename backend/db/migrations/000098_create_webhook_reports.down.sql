@@ -0,0 +1,2 @@
+-- Drop webhook_reports table
+DROP TABLE IF EXISTS webhook_reports;
@@ -0,0 +1,31 @@
+-- Create webhook_reports table holding the reports on their portfolio users have posted
+-- to their own endpoint on a cron schedule. url and secret are sealed with the
+-- encryption key when one is configured. consecutive_failures counts the deliveries
+-- failed since the last one that went through; failure_alerted is set once the owner
+-- was emailed about them.
+CREATE TABLE IF NOT EXISTS webhook_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    schedule VARCHAR(100) NOT NULL,
+    sections TEXT[] NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    last_status VARCHAR(20) CHECK (last_status IN ('delivered', 'failed')),
+    last_error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    failure_alerted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX idx_webhook_reports_user_id ON webhook_reports(user_id);
+CREATE INDEX idx_webhook_reports_due ON webhook_reports(next_run_at) WHERE enabled;
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_webhook_reports_updated_at BEFORE UPDATE
+    ON webhook_reports FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
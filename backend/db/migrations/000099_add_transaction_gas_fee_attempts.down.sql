@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_gas_fee_pending;
+ALTER TABLE transactions DROP COLUMN IF EXISTS gas_fee_retry_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS gas_fee_attempts;
@@ -0,0 +1,6 @@
+-- Transactions whose gas fee couldn't be priced are retried by the gas fee backfill with
+-- a growing delay, and given up on after a few attempts
+ALTER TABLE transactions ADD COLUMN gas_fee_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN gas_fee_retry_at TIMESTAMPTZ;
+
+CREATE INDEX idx_transactions_gas_fee_pending ON transactions(id) WHERE gas_fee_usd IS NULL;
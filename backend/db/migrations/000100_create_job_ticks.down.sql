@@ -0,0 +1,2 @@
+-- Drop job_ticks table
+DROP TABLE IF EXISTS job_ticks;
@@ -0,0 +1,7 @@
+-- Create job_ticks table holding the latest scheduled tick each worker job completed, so
+-- a replica whose cron fires after another replica already ran the tick skips it
+CREATE TABLE IF NOT EXISTS job_ticks (
+    job_name VARCHAR(100) PRIMARY KEY,
+    tick TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
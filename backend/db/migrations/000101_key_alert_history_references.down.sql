@@ -0,0 +1,11 @@
+DROP FUNCTION IF EXISTS drop_monthly_partitions(TEXT, TIMESTAMPTZ);
+
+ALTER TABLE notification_queue DROP CONSTRAINT notification_queue_history_fkey;
+ALTER TABLE alert_deliveries DROP CONSTRAINT alert_deliveries_history_fkey;
+ALTER TABLE notification_outbox DROP CONSTRAINT notification_outbox_history_fkey;
+ALTER TABLE alert_escalations DROP CONSTRAINT alert_escalations_history_fkey;
+
+ALTER TABLE notification_queue DROP COLUMN history_triggered_at;
+ALTER TABLE alert_deliveries DROP COLUMN history_triggered_at;
+ALTER TABLE notification_outbox DROP COLUMN history_triggered_at;
+ALTER TABLE alert_escalations DROP COLUMN history_triggered_at;
@@ -0,0 +1,63 @@
+-- Tables referencing alert_history carry the trigger time alongside history_id, so
+-- lookups by trigger are pruned to one partition and the foreign keys 000073 dropped
+-- can be restored on the partitioned table's (id, triggered_at) key.
+ALTER TABLE notification_queue ADD COLUMN history_triggered_at TIMESTAMPTZ;
+ALTER TABLE alert_deliveries ADD COLUMN history_triggered_at TIMESTAMPTZ;
+ALTER TABLE notification_outbox ADD COLUMN history_triggered_at TIMESTAMPTZ;
+ALTER TABLE alert_escalations ADD COLUMN history_triggered_at TIMESTAMPTZ;
+
+UPDATE notification_queue q SET history_triggered_at = h.triggered_at FROM alert_history h WHERE h.id = q.history_id;
+UPDATE alert_deliveries d SET history_triggered_at = h.triggered_at FROM alert_history h WHERE h.id = d.history_id;
+UPDATE notification_outbox o SET history_triggered_at = h.triggered_at FROM alert_history h WHERE h.id = o.history_id;
+UPDATE alert_escalations e SET history_triggered_at = h.triggered_at FROM alert_history h WHERE h.id = e.history_id;
+
+-- Rows left without a trigger while the keys were missing would have been cascaded away
+DELETE FROM notification_queue WHERE history_triggered_at IS NULL;
+DELETE FROM alert_deliveries WHERE history_triggered_at IS NULL;
+DELETE FROM notification_outbox WHERE history_triggered_at IS NULL;
+DELETE FROM alert_escalations WHERE history_triggered_at IS NULL;
+
+ALTER TABLE notification_queue ALTER COLUMN history_triggered_at SET NOT NULL;
+ALTER TABLE alert_deliveries ALTER COLUMN history_triggered_at SET NOT NULL;
+ALTER TABLE notification_outbox ALTER COLUMN history_triggered_at SET NOT NULL;
+ALTER TABLE alert_escalations ALTER COLUMN history_triggered_at SET NOT NULL;
+
+ALTER TABLE notification_queue ADD CONSTRAINT notification_queue_history_fkey
+    FOREIGN KEY (history_id, history_triggered_at) REFERENCES alert_history(id, triggered_at) ON DELETE CASCADE;
+ALTER TABLE alert_deliveries ADD CONSTRAINT alert_deliveries_history_fkey
+    FOREIGN KEY (history_id, history_triggered_at) REFERENCES alert_history(id, triggered_at) ON DELETE CASCADE;
+ALTER TABLE notification_outbox ADD CONSTRAINT notification_outbox_history_fkey
+    FOREIGN KEY (history_id, history_triggered_at) REFERENCES alert_history(id, triggered_at) ON DELETE CASCADE;
+ALTER TABLE alert_escalations ADD CONSTRAINT alert_escalations_history_fkey
+    FOREIGN KEY (history_id, history_triggered_at) REFERENCES alert_history(id, triggered_at) ON DELETE CASCADE;
+
+-- drop_monthly_partitions removes the monthly partitions of a table partitioned by
+-- range on a timestamp whose whole month ends at or before before_time. Rows are
+-- deleted first so foreign keys cascade to the rows referencing them, which would
+-- otherwise block the detach. Returns how many were dropped.
+CREATE OR REPLACE FUNCTION drop_monthly_partitions(parent TEXT, before_time TIMESTAMPTZ)
+RETURNS INTEGER AS $$
+DECLARE
+    partition_name TEXT;
+    month_start TIMESTAMP;
+    dropped INTEGER := 0;
+BEGIN
+    FOR partition_name IN
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = parent::regclass
+          AND c.relname ~ ('^' || parent || '_[0-9]{4}_[0-9]{2}$')
+        ORDER BY c.relname
+    LOOP
+        month_start := to_timestamp(right(partition_name, 7), 'YYYY_MM')::timestamp;
+        EXIT WHEN (month_start + INTERVAL '1 month') AT TIME ZONE 'UTC' > before_time;
+
+        EXECUTE format('DELETE FROM %I', partition_name);
+        EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', parent, partition_name);
+        EXECUTE format('DROP TABLE %I', partition_name);
+        dropped := dropped + 1;
+    END LOOP;
+    RETURN dropped;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1 @@
+ALTER TABLE billing_accounts DROP COLUMN IF EXISTS last_event_at;
@@ -0,0 +1,3 @@
+-- Stripe doesn't deliver events in order, so billing accounts keep when the latest
+-- event applied to them was created and older ones don't overwrite its state
+ALTER TABLE billing_accounts ADD COLUMN IF NOT EXISTS last_event_at TIMESTAMPTZ;
@@ -6,10 +6,11 @@ SELECT
     t.name,
     t.decimals,
     t.logo_uri,
-    t.price_usd as current_price,
-    t.price_change_24h
+    p.price_usd as current_price,
+    p.price_change_24h
 FROM balances b
 INNER JOIN tokens t ON t.id = b.token_id
+LEFT JOIN token_prices_latest p ON p.token_id = t.id
 WHERE b.wallet_id = $1
     AND b.balance > 0
 ORDER BY b.balance_usd DESC NULLS LAST;
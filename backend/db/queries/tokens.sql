@@ -11,21 +11,19 @@ LIMIT 1;
 -- name: CreateToken :one
 INSERT INTO tokens (
     address, chain_id, symbol, name, decimals,
-    logo_uri, price_usd, price_change_24h, market_cap,
-    total_supply, last_updated
+    logo_uri, market_cap, total_supply
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
 -- name: UpdateTokenPrice :one
-UPDATE tokens
-SET price_usd = $3,
-    price_change_24h = $4,
-    market_cap = $5,
-    last_updated = NOW(),
-    updated_at = NOW()
-WHERE address = $1 AND chain_id = $2
+INSERT INTO token_prices_latest (token_id, price_usd, price_change_24h, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (token_id) DO UPDATE SET
+    price_usd = EXCLUDED.price_usd,
+    price_change_24h = EXCLUDED.price_change_24h,
+    updated_at = EXCLUDED.updated_at
 RETURNING *;
 
 -- name: GetTokensByChainId :many
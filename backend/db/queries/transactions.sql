@@ -1,6 +1,6 @@
 -- name: GetTransactionByHash :one
 SELECT * FROM transactions
-WHERE hash = $1
+WHERE hash = $1 AND chain_id = $2
 LIMIT 1;
 
 -- name: CreateTransaction :one
@@ -20,12 +20,12 @@ SET status = $2,
     gas_used = $4,
     gas_fee_usd = $5,
     updated_at = NOW()
-WHERE hash = $1
+WHERE hash = $1 AND chain_id = $6
 RETURNING *;
 
 -- name: GetUserTransactions :many
 SELECT t.* FROM transactions t
-INNER JOIN user_transactions ut ON ut.transaction_id = t.id
+INNER JOIN user_transactions ut ON ut.transaction_id = t.id AND ut.chain_id = t.chain_id
 WHERE ut.user_id = $1
     AND ($2::int IS NULL OR t.chain_id = $2)
     AND ($3::transaction_type IS NULL OR t.type = $3)
@@ -43,6 +43,6 @@ ORDER BY timestamp DESC
 LIMIT $4 OFFSET $5;
 
 -- name: LinkTransactionToUser :exec
-INSERT INTO user_transactions (user_id, transaction_id, wallet_id)
-VALUES ($1, $2, $3)
+INSERT INTO user_transactions (user_id, transaction_id, chain_id, wallet_id)
+VALUES ($1, $2, $3, $4)
 ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,79 @@
+// Package clientgen generates the operation table of the Go SDK in pkg/client from the
+// OpenAPI spec, so the SDK's methods and paths can't drift from the documented API.
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// methods are the HTTP methods an OpenAPI path item may define operations for
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+type spec struct {
+	Paths map[string]map[string]struct {
+		OperationID string `yaml:"operationId"`
+	} `yaml:"paths"`
+}
+
+type operation struct {
+	id     string
+	method string
+	path   string
+}
+
+// Generate returns the Go source of package client's operations: one variable per
+// operationId, holding its method and path template
+func Generate(source []byte, specName string) ([]byte, error) {
+	var parsed spec
+	if err := yaml.Unmarshal(source, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	var operations []operation
+	seen := make(map[string]bool)
+	for path, item := range parsed.Paths {
+		for _, method := range methods {
+			op, ok := item[method]
+			if !ok {
+				continue
+			}
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s has no operationId", strings.ToUpper(method), path)
+			}
+			if seen[op.OperationID] {
+				return nil, fmt.Errorf("duplicate operationId %q", op.OperationID)
+			}
+			seen[op.OperationID] = true
+			operations = append(operations, operation{id: op.OperationID, method: strings.ToUpper(method), path: path})
+		}
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("spec defines no operations")
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].id < operations[j].id })
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by clientgen from %s. DO NOT EDIT.\n\n", specName)
+	out.WriteString("package client\n\n")
+	out.WriteString("// Operations of the OpenAPI spec, by operationId\n")
+	out.WriteString("var (\n")
+	for _, op := range operations {
+		fmt.Fprintf(&out, "\top%s = operation{ID: %q, Method: %q, Path: %q}\n",
+			strings.ToUpper(op.id[:1])+op.id[1:], op.id, op.method, op.path)
+	}
+	out.WriteString(")\n\n")
+	out.WriteString("// operations lists every operation of the spec\n")
+	out.WriteString("var operations = []operation{\n")
+	for _, op := range operations {
+		fmt.Fprintf(&out, "\top%s,\n", strings.ToUpper(op.id[:1])+op.id[1:])
+	}
+	out.WriteString("}\n")
+
+	return format.Source(out.Bytes())
+}
\ No newline at end of file
@@ -0,0 +1,32 @@
+package clients
+
+import "sync"
+
+// APIKey holds a provider API key that can be rotated while requests are in flight
+type APIKey struct {
+	mu    sync.RWMutex
+	value string
+}
+
+func NewAPIKey(value string) *APIKey {
+	return &APIKey{value: value}
+}
+
+// Get returns the current key
+func (k *APIKey) Get() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.value
+}
+
+// Set replaces the key; subsequent requests use the new value
+func (k *APIKey) Set(value string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.value = value
+}
+
+// KeyRotator is implemented by clients whose API key can be swapped without a restart
+type KeyRotator interface {
+	SetAPIKey(key string)
+}
@@ -2,19 +2,28 @@ package bridge
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/clock"
 )
 
+// lifiNoQuoteCode is the error code LI.FI answers with when no bridge can make the transfer
+const lifiNoQuoteCode = "1002"
+
+// lifiGasTool is the tool LI.FI's gas-on-destination legs are made with
+const lifiGasTool = "gasZip"
+
 // LiFiClient implements BridgeClient for LI.FI API
 type LiFiClient struct {
 	httpClient clients.HTTPClient
 	baseURL    string
-	apiKey     string
+	apiKey     *clients.APIKey
+	clock      clock.Clock
 }
 
 // LiFi API types
@@ -149,6 +158,20 @@ type lifiChainInfo struct {
 	NativeToken    lifiToken `json:"nativeToken"`
 }
 
+type lifiStatusResponse struct {
+	Status    string                 `json:"status"`
+	Substatus string                 `json:"substatus"`
+	Tool      string                 `json:"tool"`
+	Sending   *lifiStatusTransaction `json:"sending"`
+	Receiving *lifiStatusTransaction `json:"receiving"`
+}
+
+type lifiStatusTransaction struct {
+	TxHash  string `json:"txHash"`
+	ChainID int    `json:"chainId"`
+	Amount  string `json:"amount"`
+}
+
 type lifiTokensResponse struct {
 	Tokens map[string][]lifiToken `json:"tokens"`
 }
@@ -160,7 +183,8 @@ func NewLiFiClient(config clients.ClientConfig) *LiFiClient {
 	return &LiFiClient{
 		httpClient: httpClient,
 		baseURL:    config.BaseURL,
-		apiKey:     config.APIKey,
+		apiKey:     clients.NewAPIKey(config.APIKey),
+		clock:      clock.System,
 	}
 }
 
@@ -200,11 +224,14 @@ func (c *LiFiClient) GetQuote(ctx context.Context, req clients.QuoteRequest) (*c
 	if lifiReq.Options.Slippage > 0 {
 		q.Add("options.slippage", fmt.Sprintf("%.4f", lifiReq.Options.Slippage))
 	}
+	if req.FromAmountForGas != "" {
+		q.Add("fromAmountForGas", req.FromAmountForGas)
+	}
 	httpReq.URL.RawQuery = q.Encode()
 
 	// Add headers
-	if c.apiKey != "" {
-		httpReq.Header.Set("x-lifi-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("x-lifi-api-key", apiKey)
 	}
 	httpReq.Header.Set("Accept", "application/json")
 
@@ -215,12 +242,16 @@ func (c *LiFiClient) GetQuote(ctx context.Context, req clients.QuoteRequest) (*c
 
 	var lifiResp lifiQuoteResponse
 	if err := clients.ParseResponse(resp, &lifiResp); err != nil {
+		var apiErr *clients.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == lifiNoQuoteCode {
+			return nil, clients.ErrNoRoutes
+		}
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Convert to unified format
 	if len(lifiResp.Routes) == 0 {
-		return nil, fmt.Errorf("no routes found")
+		return nil, clients.ErrNoRoutes
 	}
 
 	// Use the first route (best route)
@@ -236,8 +267,8 @@ func (c *LiFiClient) GetSupportedChains(ctx context.Context) ([]clients.Chain, e
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if c.apiKey != "" {
-		httpReq.Header.Set("x-lifi-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("x-lifi-api-key", apiKey)
 	}
 	httpReq.Header.Set("Accept", "application/json")
 
@@ -285,8 +316,8 @@ func (c *LiFiClient) GetSupportedTokens(ctx context.Context, chainID string) ([]
 	q.Add("chains", chainID)
 	httpReq.URL.RawQuery = q.Encode()
 
-	if c.apiKey != "" {
-		httpReq.Header.Set("x-lifi-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("x-lifi-api-key", apiKey)
 	}
 	httpReq.Header.Set("Accept", "application/json")
 
@@ -322,6 +353,66 @@ func (c *LiFiClient) GetProviderName() string {
 	return "LI.FI"
 }
 
+// SetAPIKey rotates the API key used for subsequent requests
+func (c *LiFiClient) SetAPIKey(key string) {
+	c.apiKey.Set(key)
+}
+
+// GetTransferStatus looks up a transfer made through LI.FI by its source transaction
+func (c *LiFiClient) GetTransferStatus(ctx context.Context, req clients.TransferStatusRequest) (*clients.TransferStatus, error) {
+	url := fmt.Sprintf("%s/status", c.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := httpReq.URL.Query()
+	q.Add("txHash", req.TxHash)
+	if req.FromChainID != "" {
+		q.Add("fromChain", req.FromChainID)
+	}
+	if req.ToChainID != "" {
+		q.Add("toChain", req.ToChainID)
+	}
+	httpReq.URL.RawQuery = q.Encode()
+
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("x-lifi-api-key", apiKey)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+
+	var lifiResp lifiStatusResponse
+	if err := clients.ParseResponse(resp, &lifiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	status := &clients.TransferStatus{
+		Provider:     c.GetProviderName(),
+		Bridge:       lifiResp.Tool,
+		SourceTxHash: req.TxHash,
+	}
+	switch lifiResp.Status {
+	case "DONE":
+		status.Status = clients.TransferStatusDone
+	case "FAILED", "INVALID":
+		status.Status = clients.TransferStatusFailed
+	case "NOT_FOUND":
+		status.Status = clients.TransferStatusNotFound
+	default:
+		status.Status = clients.TransferStatusPending
+	}
+	if lifiResp.Receiving != nil && lifiResp.Receiving.TxHash != "" {
+		status.DestinationTxHash = lifiResp.Receiving.TxHash
+		status.DestinationChainID = strconv.Itoa(lifiResp.Receiving.ChainID)
+	}
+	return status, nil
+}
+
 // IsHealthy checks if the provider API is responding
 func (c *LiFiClient) IsHealthy(ctx context.Context) bool {
 	url := fmt.Sprintf("%s/status", c.baseURL)
@@ -330,8 +421,8 @@ func (c *LiFiClient) IsHealthy(ctx context.Context) bool {
 		return false
 	}
 
-	if c.apiKey != "" {
-		httpReq.Header.Set("x-lifi-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("x-lifi-api-key", apiKey)
 	}
 
 	resp, err := c.httpClient.Do(httpReq)
@@ -369,12 +460,21 @@ func (c *LiFiClient) convertToUnifiedQuote(route lifiRoute) *clients.Quote {
 		},
 		FromAmount: route.FromAmount,
 		ToAmount:   route.ToAmount,
-		ExpiresAt:  time.Now().Add(30 * time.Second), // LI.FI quotes expire after 30 seconds
+		ExpiresAt:  c.clock.Now().Add(30 * time.Second), // LI.FI quotes expire after 30 seconds
 	}
 
 	// Convert steps to route steps
 	var routeSteps []clients.RouteStep
 	for _, step := range route.Steps {
+		if refuel := lifiRefuel(step); refuel != nil {
+			quote.Refuel = refuel
+		}
+		if step.Tool == lifiGasTool {
+			// The gas leg is the quote's Refuel rather than a step of the transfer; its
+			// fees are still the route's
+			quote.Fees = append(quote.Fees, lifiStepFees(step)...)
+			continue
+		}
 		routeSteps = append(routeSteps, clients.RouteStep{
 			Protocol: step.Tool,
 			Type:     step.Type,
@@ -416,26 +516,62 @@ func (c *LiFiClient) convertToUnifiedQuote(route lifiRoute) *clients.Quote {
 			quote.GasPriceWei = step.TransactionRequest.GasPrice
 		}
 
-		// Convert fees
-		for _, fee := range step.Estimate.FeeCosts {
-			quote.Fees = append(quote.Fees, clients.Fee{
-				Type:        fee.Name,
-				Amount:      fee.Amount,
-				AmountUSD:   fee.AmountUSD,
-				Percentage:  fee.Percentage,
-				Description: fee.Description,
-				Token: clients.Token{
-					Address:  fee.Token.Address,
-					Symbol:   fee.Token.Symbol,
-					Name:     fee.Token.Name,
-					Decimals: fee.Token.Decimals,
-					ChainID:  strconv.Itoa(fee.Token.ChainID),
-					LogoURI:  fee.Token.LogoURI,
-				},
-			})
-		}
+		quote.Fees = append(quote.Fees, lifiStepFees(step)...)
 	}
 
 	quote.Route = routeSteps
 	return quote
-}
\ No newline at end of file
+}
+
+// lifiRefuel returns the gas-on-destination leg of a step, which LI.FI lists as a step
+// of its own or within a step's included steps
+func lifiRefuel(step lifiStep) *clients.Refuel {
+	for _, s := range append([]lifiStep{step}, step.IncludedSteps...) {
+		if s.Tool == lifiGasTool {
+			return &clients.Refuel{
+				Protocol:   s.Tool,
+				FromToken:  s.Action.FromToken.unified(),
+				FromAmount: s.Action.FromAmount,
+				ToToken:    s.Action.ToToken.unified(),
+				ToAmount:   s.Estimate.ToAmount,
+			}
+		}
+	}
+	return nil
+}
+
+func (t lifiToken) unified() clients.Token {
+	return clients.Token{
+		Address:  t.Address,
+		Symbol:   t.Symbol,
+		Name:     t.Name,
+		Decimals: t.Decimals,
+		ChainID:  strconv.Itoa(t.ChainID),
+		LogoURI:  t.LogoURI,
+	}
+}
+
+// lifiStepFees lists a step's fees and gas costs
+func lifiStepFees(step lifiStep) []clients.Fee {
+	var fees []clients.Fee
+	for _, fee := range step.Estimate.FeeCosts {
+		fees = append(fees, clients.Fee{
+			Type:        fee.Name,
+			Amount:      fee.Amount,
+			AmountUSD:   fee.AmountUSD,
+			Percentage:  fee.Percentage,
+			Description: fee.Description,
+			Token:       fee.Token.unified(),
+		})
+	}
+	for _, gas := range step.Estimate.GasCosts {
+		fees = append(fees, clients.Fee{
+			Type:        "gas",
+			Amount:      gas.Amount,
+			AmountUSD:   gas.AmountUSD,
+			Description: "Network gas",
+			Token:       gas.Token.unified(),
+		})
+	}
+	return fees
+}
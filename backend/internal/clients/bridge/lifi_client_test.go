@@ -0,0 +1,199 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUser = "0x8ba1f109551bD432803012645Ac136ddd64DBA72"
+
+// testConfig points a client at a replay server, without retries so each recorded
+// response is seen once
+func testConfig(baseURL string) clients.ClientConfig {
+	return clients.ClientConfig{
+		BaseURL:    baseURL,
+		APIKey:     "test-key",
+		Timeout:    5 * time.Second,
+		RetryDelay: time.Millisecond,
+		RateLimit:  clients.RateLimitConfig{RequestsPerSecond: 100, BurstSize: 10},
+	}
+}
+
+var usdcToPolygon = clients.QuoteRequest{
+	FromChainID: "1",
+	ToChainID:   "137",
+	FromToken:   "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	ToToken:     "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359",
+	Amount:      "1000000000",
+	UserAddress: testUser,
+	Slippage:    0.005,
+}
+
+func TestLiFiClientGetQuote(t *testing.T) {
+	server := vcr.Replay(t, "testdata/lifi/quote.json")
+	client := NewLiFiClient(testConfig(server.URL))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	client.clock = clock.NewFake(now)
+
+	quote, err := client.GetQuote(context.Background(), usdcToPolygon)
+	require.NoError(t, err)
+
+	assert.Equal(t, "0x6a1e9b3f2c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f", quote.ID, "the first route is the best one")
+	assert.Equal(t, "bridge", quote.Type)
+	assert.Equal(t, "LI.FI", quote.Provider)
+	assert.Equal(t, "1", quote.FromChainID)
+	assert.Equal(t, "137", quote.ToChainID)
+	assert.Equal(t, clients.Token{
+		Address:  "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		Symbol:   "USDC",
+		Name:     "USD Coin",
+		Decimals: 6,
+		ChainID:  "1",
+		LogoURI:  "https://static.debank.com/image/coin/logo_url/usdc/e87790bfe0b3f2ea855dc29069b38818.png",
+	}, quote.FromToken)
+	assert.Equal(t, "137", quote.ToToken.ChainID)
+	assert.Equal(t, "1000000000", quote.FromAmount)
+	assert.Equal(t, "999387214", quote.ToAmount)
+	assert.Equal(t, 180*time.Second, quote.EstimatedTime)
+	assert.Equal(t, "0x48995", quote.EstimatedGas)
+	assert.Equal(t, "0x4495bc6ff", quote.GasPriceWei)
+	assert.Equal(t, now.Add(30*time.Second), quote.ExpiresAt)
+
+	require.Len(t, quote.Route, 1)
+	assert.Equal(t, "stargateV2", quote.Route[0].Protocol)
+	assert.Equal(t, "cross", quote.Route[0].Type)
+	assert.Equal(t, "137", quote.Route[0].ToToken.ChainID)
+
+	require.NotNil(t, quote.TransactionData)
+	assert.Equal(t, clients.TransactionData{
+		To:       "0x1231DEB6f5749EF6cE6943a275A1D3E7486F4EaE",
+		Data:     "0x4630a0d8a1b2c3d4",
+		Value:    "0x3bf0a9ac8c1d2",
+		GasLimit: "0x48995",
+		ChainID:  "1",
+	}, *quote.TransactionData)
+
+	require.Len(t, quote.Fees, 2)
+	assert.Equal(t, "LIFI Fixed Fee", quote.Fees[0].Type)
+	assert.Equal(t, "250000", quote.Fees[0].Amount)
+	assert.Equal(t, "0.25", quote.Fees[0].AmountUSD)
+	assert.Equal(t, "USDC", quote.Fees[0].Token.Symbol)
+	assert.Equal(t, "gas", quote.Fees[1].Type)
+	assert.Equal(t, "4211142374815762", quote.Fees[1].Amount)
+	assert.Equal(t, "4.21", quote.Fees[1].AmountUSD)
+	assert.Equal(t, "ETH", quote.Fees[1].Token.Symbol)
+}
+
+func TestLiFiClientGetQuoteRefuel(t *testing.T) {
+	server := vcr.Replay(t, "testdata/lifi/quote_refuel.json")
+	client := NewLiFiClient(testConfig(server.URL))
+
+	req := usdcToPolygon
+	req.FromAmountForGas = "2000000"
+	quote, err := client.GetQuote(context.Background(), req)
+	require.NoError(t, err)
+
+	require.NotNil(t, quote.Refuel)
+	assert.Equal(t, "gasZip", quote.Refuel.Protocol)
+	assert.Equal(t, "2000000", quote.Refuel.FromAmount)
+	assert.Equal(t, "POL", quote.Refuel.ToToken.Symbol)
+	assert.Equal(t, "137", quote.Refuel.ToToken.ChainID)
+	assert.Equal(t, "8395000000000000000", quote.Refuel.ToAmount)
+
+	// The gas leg isn't a step of the transfer, but its fee is the route's
+	require.Len(t, quote.Route, 1)
+	assert.Equal(t, "stargateV2", quote.Route[0].Protocol)
+	require.Len(t, quote.Fees, 1)
+	assert.Equal(t, "Gas.zip fee", quote.Fees[0].Type)
+}
+
+func TestLiFiClientGetQuoteErrors(t *testing.T) {
+	server := vcr.Replay(t, "testdata/lifi/quote_errors.json")
+	client := NewLiFiClient(testConfig(server.URL))
+	ctx := context.Background()
+
+	// A transfer no bridge can make comes back as a 404 with LI.FI's no-quote code
+	_, err := client.GetQuote(ctx, usdcToPolygon)
+	assert.ErrorIs(t, err, clients.ErrNoRoutes)
+
+	_, err = client.GetQuote(ctx, usdcToPolygon)
+	assert.ErrorIs(t, err, clients.ErrNoRoutes)
+
+	_, err = client.GetQuote(ctx, usdcToPolygon)
+	var apiErr *clients.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, "1011", apiErr.Code)
+	assert.Contains(t, apiErr.Message, "is not supported on chain 137")
+
+	// Gateway errors have no JSON body to read a message from
+	_, err = client.GetQuote(ctx, usdcToPolygon)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 502, apiErr.StatusCode)
+	assert.EqualError(t, apiErr, "HTTP 502: Bad Gateway")
+}
+
+func TestLiFiClientChainsAndTokens(t *testing.T) {
+	server := vcr.Replay(t, "testdata/lifi/chains_tokens.json")
+	client := NewLiFiClient(testConfig(server.URL))
+	ctx := context.Background()
+
+	chains, err := client.GetSupportedChains(ctx)
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+	assert.Equal(t, "137", chains[0].ID)
+	assert.Equal(t, "POL", chains[0].NativeCurrency.Symbol)
+	assert.False(t, chains[0].IsTestnet)
+	assert.True(t, chains[1].IsTestnet, "testnets point at their mainnet")
+
+	tokens, err := client.GetSupportedTokens(ctx, "137")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, clients.Token{
+		Address:  "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359",
+		Symbol:   "USDC",
+		Name:     "USD Coin",
+		Decimals: 6,
+		ChainID:  "137",
+		LogoURI:  "https://static.debank.com/image/coin/logo_url/usdc/e87790bfe0b3f2ea855dc29069b38818.png",
+	}, tokens[0])
+}
+
+func TestLiFiClientGetTransferStatus(t *testing.T) {
+	server := vcr.Replay(t, "testdata/lifi/status.json")
+	client := NewLiFiClient(testConfig(server.URL))
+	ctx := context.Background()
+	req := clients.TransferStatusRequest{
+		FromChainID: "1",
+		ToChainID:   "137",
+		TxHash:      "0x9fc76d4c3b2a1e0f8d7c6b5a49382716e5d4c3b2a19f8e7d6c5b4a3928171605",
+	}
+
+	status, err := client.GetTransferStatus(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.TransferStatus{
+		Provider:           "LI.FI",
+		Status:             clients.TransferStatusDone,
+		Bridge:             "stargateV2",
+		SourceTxHash:       req.TxHash,
+		DestinationChainID: "137",
+		DestinationTxHash:  "0x2b4c6d8e0f1a3b5c7d9e1f2a4b6c8d0e2f4a6b8c0d2e4f6a8b0c2d4e6f8a0b2c",
+	}, status)
+
+	status, err = client.GetTransferStatus(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, clients.TransferStatusPending, status.Status)
+	assert.Empty(t, status.DestinationTxHash, "no destination until the transfer lands")
+
+	status, err = client.GetTransferStatus(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, clients.TransferStatusNotFound, status.Status)
+}
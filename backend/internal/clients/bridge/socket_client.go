@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/clock"
 )
 
 // SocketClient implements BridgeClient for Socket API
@@ -15,6 +16,7 @@ type SocketClient struct {
 	httpClient clients.HTTPClient
 	baseURL    string
 	apiKey     string
+	clock      clock.Clock
 }
 
 // Socket API types
@@ -61,6 +63,18 @@ type socketRoute struct {
 	ServiceTime          int                  `json:"serviceTime"`
 	MaxServiceTime       int                  `json:"maxServiceTime"`
 	IntegratorFee        socketIntegratorFee  `json:"integratorFee"`
+	// Refuel is set when the route was asked for with bridgeWithGas and Socket's refuel
+	// bridge serves the chains
+	Refuel *socketRefuel `json:"refuel,omitempty"`
+}
+
+type socketRefuel struct {
+	FromAmount  string        `json:"fromAmount"`
+	ToAmount    string        `json:"toAmount"`
+	FromAsset   socketToken   `json:"fromAsset"`
+	ToAsset     socketToken   `json:"toAsset"`
+	GasFees     socketGasFees `json:"gasFees"`
+	ServiceTime int           `json:"serviceTime"`
 }
 
 type socketUserTx struct {
@@ -178,6 +192,21 @@ type socketChainCurrency struct {
 	ChainId     int    `json:"chainId"`
 }
 
+type socketStatusResponse struct {
+	Success bool               `json:"success"`
+	Result  socketStatusResult `json:"result"`
+}
+
+type socketStatusResult struct {
+	SourceTx                   string `json:"sourceTx"`
+	SourceTxStatus             string `json:"sourceTxStatus"`
+	DestinationTransactionHash string `json:"destinationTransactionHash"`
+	DestinationTxStatus        string `json:"destinationTxStatus"`
+	FromChainID                int    `json:"fromChainId"`
+	ToChainID                  int    `json:"toChainId"`
+	BridgeName                 string `json:"bridgeName"`
+}
+
 // NewSocketClient creates a new Socket bridge client
 func NewSocketClient(config clients.ClientConfig) *SocketClient {
 	httpClient := clients.NewBaseHTTPClient(config)
@@ -186,6 +215,7 @@ func NewSocketClient(config clients.ClientConfig) *SocketClient {
 		httpClient: httpClient,
 		baseURL:    config.BaseURL,
 		apiKey:     config.APIKey,
+		clock:      clock.System,
 	}
 }
 
@@ -207,7 +237,16 @@ func (c *SocketClient) GetQuote(ctx context.Context, req clients.QuoteRequest) (
 	q.Add("userAddress", req.UserAddress)
 	q.Add("uniqueRoutesPerBridge", "true")
 	q.Add("sort", "output")
-	q.Add("singleTxn", "false")
+	q.Add("singleTxn", strconv.FormatBool(!req.AllowMultiTx))
+	if req.Slippage > 0 {
+		slippage := strconv.FormatFloat(req.Slippage, 'f', -1, 64)
+		q.Add("defaultSwapSlippage", slippage)
+		q.Add("defaultBridgeSlippage", slippage)
+	}
+	if req.FromAmountForGas != "" {
+		// Socket's refuel picks how much gas to deliver itself
+		q.Add("bridgeWithGas", "true")
+	}
 	httpReq.URL.RawQuery = q.Encode()
 
 	// Add headers
@@ -232,7 +271,7 @@ func (c *SocketClient) GetQuote(ctx context.Context, req clients.QuoteRequest) (
 
 	// Convert to unified format
 	if len(socketResp.Result.Routes) == 0 {
-		return nil, fmt.Errorf("no routes found")
+		return nil, clients.ErrNoRoutes
 	}
 
 	// Use the first route (best route by output)
@@ -340,6 +379,61 @@ func (c *SocketClient) GetProviderName() string {
 	return "Socket"
 }
 
+// GetTransferStatus looks up a transfer made through Socket by its source transaction.
+// Socket needs both chains to find it.
+func (c *SocketClient) GetTransferStatus(ctx context.Context, req clients.TransferStatusRequest) (*clients.TransferStatus, error) {
+	url := fmt.Sprintf("%s/bridge-status", c.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := httpReq.URL.Query()
+	q.Add("transactionHash", req.TxHash)
+	q.Add("fromChainId", req.FromChainID)
+	q.Add("toChainId", req.ToChainID)
+	httpReq.URL.RawQuery = q.Encode()
+
+	if c.apiKey != "" {
+		httpReq.Header.Set("API-KEY", c.apiKey)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+
+	var socketResp socketStatusResponse
+	if err := clients.ParseResponse(resp, &socketResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !socketResp.Success {
+		return nil, fmt.Errorf("Socket API returned error")
+	}
+
+	result := socketResp.Result
+	status := &clients.TransferStatus{
+		Provider:          c.GetProviderName(),
+		Bridge:            result.BridgeName,
+		SourceTxHash:      req.TxHash,
+		DestinationTxHash: result.DestinationTransactionHash,
+	}
+	if result.DestinationTransactionHash != "" {
+		status.DestinationChainID = strconv.Itoa(result.ToChainID)
+	}
+	switch {
+	case result.SourceTxStatus == "FAILED" || result.DestinationTxStatus == "FAILED":
+		status.Status = clients.TransferStatusFailed
+	case result.DestinationTxStatus == "COMPLETED":
+		status.Status = clients.TransferStatusDone
+	default:
+		status.Status = clients.TransferStatusPending
+	}
+	return status, nil
+}
+
 // IsHealthy checks if the provider API is responding
 func (c *SocketClient) IsHealthy(ctx context.Context) bool {
 	url := fmt.Sprintf("%s/supported/chains", c.baseURL)
@@ -388,7 +482,7 @@ func (c *SocketClient) convertToUnifiedQuote(route socketRoute, result socketRes
 		FromAmount:    route.FromAmount,
 		ToAmount:      route.ToAmount,
 		EstimatedTime: time.Duration(route.ServiceTime) * time.Second,
-		ExpiresAt:     time.Now().Add(60 * time.Second), // Socket quotes expire after 60 seconds
+		ExpiresAt:     c.clock.Now().Add(60 * time.Second), // Socket quotes expire after 60 seconds
 	}
 
 	// Convert user transactions to route steps
@@ -442,8 +536,8 @@ func (c *SocketClient) convertToUnifiedQuote(route socketRoute, result socketRes
 		}
 	}
 
-	// Add integrator fee if present
-	if route.IntegratorFee.Amount != "" {
+	// Add integrator fee if present; Socket reports "0" when no integrator fee is set
+	if route.IntegratorFee.Amount != "" && route.IntegratorFee.Amount != "0" {
 		quote.Fees = append(quote.Fees, clients.Fee{
 			Type:   "protocol",
 			Amount: route.IntegratorFee.Amount,
@@ -458,6 +552,40 @@ func (c *SocketClient) convertToUnifiedQuote(route socketRoute, result socketRes
 		})
 	}
 
+	if route.Refuel != nil {
+		quote.Refuel = &clients.Refuel{
+			Protocol:   "refuel",
+			FromToken:  route.Refuel.FromAsset.unified(),
+			FromAmount: route.Refuel.FromAmount,
+			ToToken:    route.Refuel.ToAsset.unified(),
+			ToAmount:   route.Refuel.ToAmount,
+		}
+		if gas := route.Refuel.GasFees; gas.GasAmount != "" {
+			quote.Fees = append(quote.Fees, clients.Fee{
+				Type:        "gas",
+				Amount:      gas.GasAmount,
+				AmountUSD:   fmt.Sprintf("%.6f", gas.FeesInUsd),
+				Description: "Refuel gas",
+				Token:       gas.Asset.unified(),
+			})
+		}
+	}
+
 	quote.Route = routeSteps
 	return quote
-}
\ No newline at end of file
+}
+
+func (t socketToken) unified() clients.Token {
+	logo := t.LogoURI
+	if logo == "" {
+		logo = t.Icon
+	}
+	return clients.Token{
+		Address:  t.Address,
+		Symbol:   t.Symbol,
+		Name:     t.Name,
+		Decimals: t.Decimals,
+		ChainID:  strconv.Itoa(t.ChainId),
+		LogoURI:  logo,
+	}
+}
@@ -0,0 +1,144 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketClientGetQuote(t *testing.T) {
+	server := vcr.Replay(t, "testdata/socket/quote.json")
+	client := NewSocketClient(testConfig(server.URL))
+
+	// The recorded quote allows multi-transaction routes; by default only single ones are asked for
+	req := usdcToPolygon
+	req.AllowMultiTx = true
+	quote, err := client.GetQuote(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1f4c8a2e-6b7d-4f39-8e21-5a0c9d3b7e64", quote.ID)
+	assert.Equal(t, "Socket", quote.Provider)
+	assert.Equal(t, "1", quote.FromChainID)
+	assert.Equal(t, "137", quote.ToChainID)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", quote.FromToken.Address)
+	assert.Equal(t, "USDC", quote.ToToken.Symbol)
+	assert.Equal(t, "137", quote.ToToken.ChainID)
+	assert.Equal(t, "998612407", quote.ToAmount)
+	assert.Equal(t, 1020*time.Second, quote.EstimatedTime)
+	assert.Equal(t, "219000", quote.EstimatedGas)
+
+	require.Len(t, quote.Route, 1)
+	assert.Equal(t, "cctp", quote.Route[0].Protocol)
+	assert.Equal(t, "bridge", quote.Route[0].Type)
+
+	require.Len(t, quote.Fees, 1, "a zero integrator fee is left out")
+	assert.Equal(t, "gas", quote.Fees[0].Type)
+	assert.Equal(t, "276109541283710", quote.Fees[0].Amount)
+	assert.Equal(t, "5.084312", quote.Fees[0].AmountUSD)
+	assert.Equal(t, "ETH", quote.Fees[0].Token.Symbol)
+}
+
+func TestSocketClientGetQuoteRefuel(t *testing.T) {
+	server := vcr.Replay(t, "testdata/socket/quote_refuel.json")
+	client := NewSocketClient(testConfig(server.URL))
+
+	req := usdcToPolygon
+	req.FromAmountForGas = "2000000"
+	quote, err := client.GetQuote(context.Background(), req)
+	require.NoError(t, err)
+
+	require.NotNil(t, quote.Refuel)
+	assert.Equal(t, "refuel", quote.Refuel.Protocol)
+	assert.Equal(t, "ETH", quote.Refuel.FromToken.Symbol)
+	assert.Equal(t, "1200000000000000", quote.Refuel.FromAmount)
+	assert.Equal(t, "POL", quote.Refuel.ToToken.Symbol)
+	assert.Equal(t, "9120000000000000000", quote.Refuel.ToAmount)
+
+	require.Len(t, quote.Fees, 1)
+	assert.Equal(t, "gas", quote.Fees[0].Type)
+	assert.Equal(t, "Refuel gas", quote.Fees[0].Description)
+	assert.Equal(t, "0.120000", quote.Fees[0].AmountUSD)
+}
+
+func TestSocketClientGetQuoteErrors(t *testing.T) {
+	server := vcr.Replay(t, "testdata/socket/quote_errors.json")
+	client := NewSocketClient(testConfig(server.URL))
+	ctx := context.Background()
+
+	_, err := client.GetQuote(ctx, usdcToPolygon)
+	assert.ErrorIs(t, err, clients.ErrNoRoutes)
+
+	_, err = client.GetQuote(ctx, usdcToPolygon)
+	assert.EqualError(t, err, "Socket API returned error")
+
+	_, err = client.GetQuote(ctx, usdcToPolygon)
+	var apiErr *clients.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 401, apiErr.StatusCode)
+	assert.Equal(t, "Invalid API key", apiErr.Message)
+}
+
+func TestSocketClientChainsAndTokens(t *testing.T) {
+	server := vcr.Replay(t, "testdata/socket/chains_tokens.json")
+	client := NewSocketClient(testConfig(server.URL))
+	ctx := context.Background()
+
+	chains, err := client.GetSupportedChains(ctx)
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	assert.Equal(t, "42161", chains[0].ID)
+	assert.Equal(t, "Arbitrum", chains[0].Name)
+	assert.Equal(t, clients.Token{
+		Address:  "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee",
+		Symbol:   "ETH",
+		Name:     "Ethereum",
+		Decimals: 18,
+		ChainID:  "42161",
+	}, chains[0].NativeCurrency)
+
+	tokens, err := client.GetSupportedTokens(ctx, "42161")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "0xaf88d065e77c8cc2239327c5edb3a432268e5831", tokens[0].Address)
+	assert.Equal(t, 6, tokens[0].Decimals)
+	assert.Equal(t, "42161", tokens[0].ChainID)
+}
+
+func TestSocketClientGetTransferStatus(t *testing.T) {
+	server := vcr.Replay(t, "testdata/socket/status.json")
+	client := NewSocketClient(testConfig(server.URL))
+	ctx := context.Background()
+	req := clients.TransferStatusRequest{
+		FromChainID: "1",
+		ToChainID:   "137",
+		TxHash:      "0x4d3c2b1a0f9e8d7c6b5a4938271605f4e3d2c1b0a9f8e7d6c5b4a39281706f5e",
+	}
+
+	status, err := client.GetTransferStatus(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, &clients.TransferStatus{
+		Provider:           "Socket",
+		Status:             clients.TransferStatusDone,
+		Bridge:             "cctp",
+		SourceTxHash:       req.TxHash,
+		DestinationChainID: "137",
+		DestinationTxHash:  "0x7e6f5d4c3b2a19087f6e5d4c3b2a19087f6e5d4c3b2a19087f6e5d4c3b2a1908",
+	}, status)
+
+	// A null destination hash decodes as empty
+	status, err = client.GetTransferStatus(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, clients.TransferStatusPending, status.Status)
+	assert.Empty(t, status.DestinationTxHash)
+	assert.Empty(t, status.DestinationChainID)
+
+	status, err = client.GetTransferStatus(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, clients.TransferStatusFailed, status.Status)
+}
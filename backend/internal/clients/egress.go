@@ -0,0 +1,117 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrEgressDenied is returned for requests to a host outside the egress allowlist
+var ErrEgressDenied = errors.New("outbound host not in the egress allowlist")
+
+// ParseProxyURL parses a proxy URL given in configuration
+func ParseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy URL scheme must be http, https or socks5, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL has no host")
+	}
+	return u, nil
+}
+
+// HostAllowed reports whether host, with or without a port, is on the allowlist. An
+// entry allows its exact host; one starting with "*." or "." also allows its subdomains.
+func HostAllowed(host string, allowed []string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if suffix := strings.TrimPrefix(entry, "*"); strings.HasPrefix(suffix, ".") {
+			if strings.HasSuffix(host, suffix) || host == suffix[1:] {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// egressTransport refuses requests to hosts outside the allowlist before they leave
+type egressTransport struct {
+	base    http.RoundTripper
+	allowed []string
+}
+
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !HostAllowed(req.URL.Host, t.allowed) {
+		return nil, fmt.Errorf("%w: %s", ErrEgressDenied, req.URL.Hostname())
+	}
+	return t.base.RoundTrip(req)
+}
+
+// sharedEgress is the policy EgressTransport follows, nil until ConfigureEgress is called
+var sharedEgress atomic.Pointer[egressPolicy]
+
+type egressPolicy struct {
+	allowed []string
+	proxy   *http.Transport // http.DefaultTransport through the proxy, nil without one
+}
+
+// ConfigureEgress sets the proxy and allowlist EgressTransport applies. The API and
+// worker call it at startup with OUTBOUND_PROXY_URL and EGRESS_ALLOWED_HOSTS.
+func ConfigureEgress(proxyURL string, allowedHosts []string) error {
+	policy := &egressPolicy{allowed: allowedHosts}
+	if proxyURL != "" {
+		u, err := ParseProxyURL(proxyURL)
+		if err != nil {
+			return err
+		}
+		if defaults, ok := http.DefaultTransport.(*http.Transport); ok {
+			policy.proxy = defaults.Clone()
+			policy.proxy.Proxy = http.ProxyURL(u)
+		}
+	}
+	sharedEgress.Store(policy)
+	return nil
+}
+
+// EgressTransport returns the round tripper the provider clients that don't take a
+// ClientConfig share. It applies the policy set with ConfigureEgress when each request
+// is sent, so clients built before startup configuration still follow it. Requests
+// otherwise go through http.DefaultTransport; mocked requests don't use the proxy.
+func EgressTransport() http.RoundTripper {
+	return sharedEgressTransport{}
+}
+
+type sharedEgressTransport struct{}
+
+func (sharedEgressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := http.DefaultTransport
+	policy := sharedEgress.Load()
+	if policy == nil {
+		return base.RoundTrip(req)
+	}
+	if len(policy.allowed) > 0 && !HostAllowed(req.URL.Host, policy.allowed) {
+		return nil, fmt.Errorf("%w: %s", ErrEgressDenied, req.URL.Hostname())
+	}
+	if _, ok := base.(*http.Transport); !ok || policy.proxy == nil {
+		return base.RoundTrip(req)
+	}
+	return policy.proxy.RoundTrip(req)
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"li.quest", "*.0x.org", ".1inch.io"}
+	for host, want := range map[string]bool{
+		"li.quest":            true,
+		"LI.QUEST:443":        true,
+		"api.li.quest":        false,
+		"api.0x.org":          true,
+		"0x.org":              true,
+		"arbitrum.api.0x.org": true,
+		"evil0x.org":          false,
+		"api.1inch.io":        true,
+		"api.socket.tech":     false,
+		"169.254.169.254":     false,
+	} {
+		assert.Equal(t, want, HostAllowed(host, allowed), host)
+	}
+}
+
+func TestParseProxyURL(t *testing.T) {
+	for _, raw := range []string{"http://proxy.internal:3128", "https://proxy.internal", "socks5://127.0.0.1:1080"} {
+		_, err := ParseProxyURL(raw)
+		assert.NoError(t, err, raw)
+	}
+	for _, raw := range []string{"proxy.internal:3128", "ftp://proxy.internal", "http://", "://bad"} {
+		_, err := ParseProxyURL(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestBaseHTTPClientEgressAllowlist(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	config := ClientConfig{Timeout: time.Second, RateLimit: RateLimitConfig{RequestsPerSecond: 100, BurstSize: 10}}
+
+	config.AllowedHosts = []string{"api.example.com"}
+	_, err := NewBaseHTTPClient(config).Get(server.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrEgressDenied))
+
+	config.AllowedHosts = []string{serverURL.Hostname()}
+	resp, err := NewBaseHTTPClient(config).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestBaseHTTPClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Plain http requests reach a proxy with the absolute URL they're for
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	config := ClientConfig{
+		Timeout:   time.Second,
+		RateLimit: RateLimitConfig{RequestsPerSecond: 100, BurstSize: 10},
+		ProxyURL:  proxy.URL,
+	}
+	resp, err := NewBaseHTTPClient(config).Get("http://provider.example/v1/quote")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "http://provider.example/v1/quote", proxied)
+}
+
+func TestEgressTransport(t *testing.T) {
+	t.Cleanup(func() { sharedEgress.Store(nil) })
+
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+	client := &http.Client{Timeout: time.Second, Transport: EgressTransport()}
+
+	// Clients built before configuration pick it up on their next request
+	require.NoError(t, ConfigureEgress(proxy.URL, []string{"provider.example"}))
+	_, err := client.Get("http://other.example/v1/prices")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrEgressDenied))
+
+	resp, err := client.Get("http://provider.example/v1/prices")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "http://provider.example/v1/prices", proxied)
+
+	assert.Error(t, ConfigureEgress("ftp://proxy.internal", nil))
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/defi-dashboard/backend/pkg/correlation"
 	"golang.org/x/time/rate"
 )
 
@@ -28,9 +29,11 @@ func NewBaseHTTPClient(config ClientConfig) *BaseHTTPClient {
 		config.RateLimit.BurstSize,
 	)
 
-	// Create HTTP client with timeout
+	// Create HTTP client with timeout; calls carry the ID of the request they're made for,
+	// through the configured proxy and only to allowed hosts
 	httpClient := &http.Client{
-		Timeout: config.Timeout,
+		Timeout:   config.Timeout,
+		Transport: correlation.NewTransport(newTransport(config)),
 	}
 
 	return &BaseHTTPClient{
@@ -164,11 +167,8 @@ func ParseResponse[T any](resp *http.Response, target *T) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-			return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
-		}
-		return fmt.Errorf("API error [%s]: %s", errResp.Code, errResp.Message)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return parseAPIError(resp.StatusCode, body)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
@@ -176,4 +176,45 @@ func ParseResponse[T any](resp *http.Response, target *T) error {
 	}
 
 	return nil
+}
+
+// maxErrorBodySize bounds how much of an error response is read
+const maxErrorBodySize = 64 << 10
+
+// parseAPIError maps an error response to an APIError. Providers disagree on the error
+// shape: LI.FI sends a numeric code and a message, 0x a reason with validation errors
+// and 1inch a description, so the first message-like field found is used.
+func parseAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return apiErr
+	}
+
+	if raw, ok := fields["code"]; ok {
+		var code interface{}
+		if err := json.Unmarshal(raw, &code); err == nil && code != nil {
+			apiErr.Code = fmt.Sprint(code)
+		}
+	}
+	for _, key := range []string{"message", "description", "reason", "error"} {
+		var message string
+		if err := json.Unmarshal(fields[key], &message); err == nil && message != "" {
+			apiErr.Message = message
+			break
+		}
+	}
+
+	var validationErrors []struct {
+		Field  string `json:"field"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(fields["validationErrors"], &validationErrors); err == nil {
+		for _, validationErr := range validationErrors {
+			apiErr.Message += fmt.Sprintf("; %s: %s", validationErr.Field, validationErr.Reason)
+		}
+	}
+
+	return apiErr
 }
\ No newline at end of file
@@ -24,6 +24,13 @@ type BridgeClient interface {
 	IsHealthy(ctx context.Context) bool
 }
 
+// BridgeStatusClient is implemented by bridge providers that report the progress of
+// transfers made through them
+type BridgeStatusClient interface {
+	// GetTransferStatus looks up a transfer by its source transaction
+	GetTransferStatus(ctx context.Context, req TransferStatusRequest) (*TransferStatus, error)
+}
+
 // SwapClient defines the interface for swap providers
 type SwapClient interface {
 	// GetQuote fetches a swap quote from the provider
@@ -71,6 +78,39 @@ type ClientConfig struct {
 	MaxRetries  int
 	RetryDelay  time.Duration
 	RateLimit   RateLimitConfig
+	// ProxyURL sends the client's requests through an http, https or socks5 proxy
+	ProxyURL string
+	// AllowedHosts, when set, are the only hosts the client may call; see HostAllowed
+	AllowedHosts []string
+	// Transport tunes the client's connections; zero fields keep Go's defaults
+	Transport TransportConfig
+}
+
+// TransportConfig tunes the connections of one provider's client
+type TransportConfig struct {
+	// ConnectTimeout bounds dialling the provider
+	ConnectTimeout      time.Duration
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for the response once the request is sent
+	ResponseHeaderTimeout time.Duration
+	// MaxIdleConns is how many idle connections to the provider are kept for reuse
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+}
+
+// IsZero reports whether the config leaves everything at Go's defaults
+func (t TransportConfig) IsZero() bool {
+	return t == TransportConfig{}
+}
+
+// DefaultQuoteTransport suits quote providers, where a slow provider shouldn't hold up
+// a quote the others can answer: connections fail fast and are kept warm for reuse
+var DefaultQuoteTransport = TransportConfig{
+	ConnectTimeout:        2 * time.Second,
+	TLSHandshakeTimeout:   3 * time.Second,
+	ResponseHeaderTimeout: 10 * time.Second,
+	MaxIdleConns:          32,
+	IdleConnTimeout:       90 * time.Second,
 }
 
 // RateLimitConfig holds rate limiting configuration
@@ -0,0 +1,155 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	// DefaultQuoteStaleTTL is how long past expiry a quote is still served while it's refreshed
+	DefaultQuoteStaleTTL = 30 * time.Second
+	// DefaultQuoteNegativeTTL is how long a provider's "no route" answer is remembered
+	DefaultQuoteNegativeTTL = 10 * time.Second
+
+	// quoteFetchTimeout bounds provider calls, which outlive the request that started them
+	quoteFetchTimeout = 20 * time.Second
+	// quoteSweepInterval is how often entries past their stale window are dropped
+	quoteSweepInterval = time.Minute
+)
+
+// QuoteFetcher fetches a quote from a provider
+type QuoteFetcher func(ctx context.Context) (*Quote, error)
+
+// QuoteCache caches provider quotes without letting popular keys stampede the provider
+// when they expire:
+//   - concurrent lookups of a missing key share one provider call
+//   - an expired quote is served for a grace period while a single background call
+//     refreshes it
+//   - ErrNoRoutes answers are cached briefly so impossible routes aren't re-asked
+//
+// Other provider errors aren't cached.
+type QuoteCache struct {
+	mu          sync.Mutex
+	entries     map[string]*quoteEntry
+	inflight    map[string]*quoteCall
+	staleTTL    time.Duration
+	negativeTTL time.Duration
+	lastSweep   time.Time
+	clock       clock.Clock
+}
+
+type quoteEntry struct {
+	// quote is nil for a cached "no route" answer
+	quote      *Quote
+	freshUntil time.Time
+	staleUntil time.Time
+}
+
+type quoteCall struct {
+	done  chan struct{}
+	quote *Quote
+	err   error
+}
+
+func NewQuoteCache(staleTTL, negativeTTL time.Duration) *QuoteCache {
+	return &QuoteCache{
+		entries:     make(map[string]*quoteEntry),
+		inflight:    make(map[string]*quoteCall),
+		staleTTL:    staleTTL,
+		negativeTTL: negativeTTL,
+		clock:       clock.System,
+	}
+}
+
+// Get returns the quote cached under key, calling fetch when there's none. Fetched
+// quotes are fresh for ttl. A cached "no route" answer is returned as ErrNoRoutes.
+func (c *QuoteCache) Get(ctx context.Context, key string, ttl time.Duration, fetch QuoteFetcher) (*Quote, error) {
+	c.mu.Lock()
+	now := c.clock.Now()
+	c.sweep(now)
+
+	if entry, ok := c.entries[key]; ok {
+		if now.Before(entry.freshUntil) {
+			c.mu.Unlock()
+			if entry.quote == nil {
+				return nil, ErrNoRoutes
+			}
+			return entry.quote, nil
+		}
+		if entry.quote != nil && now.Before(entry.staleUntil) {
+			c.fetchLocked(ctx, key, ttl, fetch)
+			c.mu.Unlock()
+			return entry.quote, nil
+		}
+	}
+
+	call := c.fetchLocked(ctx, key, ttl, fetch)
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.quote, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchLocked starts a provider call for key unless one is already running, returning
+// the call either way. The call runs detached so callers giving up don't cancel it for
+// the others waiting on it; it carries the request ID of the caller that started it.
+func (c *QuoteCache) fetchLocked(ctx context.Context, key string, ttl time.Duration, fetch QuoteFetcher) *quoteCall {
+	if call, ok := c.inflight[key]; ok {
+		return call
+	}
+
+	call := &quoteCall{done: make(chan struct{})}
+	c.inflight[key] = call
+
+	detached := correlation.Detach(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(detached, quoteFetchTimeout)
+		defer cancel()
+		quote, err := fetch(ctx)
+
+		c.mu.Lock()
+		now := c.clock.Now()
+		switch {
+		case err == nil:
+			c.entries[key] = &quoteEntry{quote: quote, freshUntil: now.Add(ttl), staleUntil: now.Add(ttl + c.staleTTL)}
+		case errors.Is(err, ErrNoRoutes):
+			c.entries[key] = &quoteEntry{freshUntil: now.Add(c.negativeTTL), staleUntil: now.Add(c.negativeTTL)}
+		}
+		delete(c.inflight, key)
+		c.mu.Unlock()
+
+		call.quote, call.err = quote, err
+		close(call.done)
+	}()
+
+	return call
+}
+
+// sweep drops entries past their stale window, at most once per sweep interval
+func (c *QuoteCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < quoteSweepInterval {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if !now.Before(entry.staleUntil) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Size returns the number of cached entries, including ones being served stale
+func (c *QuoteCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
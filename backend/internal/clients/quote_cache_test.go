@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuoteCache() (*QuoteCache, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cache := NewQuoteCache(30*time.Second, 10*time.Second)
+	cache.clock = fake
+	return cache, fake
+}
+
+func TestQuoteCacheSharesConcurrentFetches(t *testing.T) {
+	cache, _ := newTestQuoteCache()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	fetch := func(ctx context.Context) (*Quote, error) {
+		calls.Add(1)
+		<-release
+		return &Quote{ID: "q1"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*Quote, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			quote, err := cache.Get(context.Background(), "lifi:1:137", time.Minute, fetch)
+			assert.NoError(t, err)
+			results[i] = quote
+		}(i)
+	}
+	// Let every caller queue up behind the first fetch
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, quote := range results {
+		require.NotNil(t, quote)
+		assert.Equal(t, "q1", quote.ID)
+	}
+}
+
+func TestQuoteCacheServesStaleWhileRevalidating(t *testing.T) {
+	cache, fake := newTestQuoteCache()
+	ctx := context.Background()
+	version := atomic.Int32{}
+	refreshed := make(chan struct{}, 1)
+	fetch := func(ctx context.Context) (*Quote, error) {
+		v := version.Add(1)
+		if v > 1 {
+			refreshed <- struct{}{}
+		}
+		return &Quote{ID: string(rune('0' + v))}, nil
+	}
+
+	quote, err := cache.Get(ctx, "key", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "1", quote.ID)
+
+	// Past its TTL but inside the stale window the old quote comes back at once
+	fake.Advance(time.Minute + 10*time.Second)
+	quote, err = cache.Get(ctx, "key", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "1", quote.ID)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale quote was not refreshed")
+	}
+	require.Eventually(t, func() bool {
+		quote, err := cache.Get(ctx, "key", time.Minute, fetch)
+		return err == nil && quote.ID == "2"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), version.Load())
+
+	// Past the stale window it's a miss
+	fake.Advance(2 * time.Minute)
+	quote, err = cache.Get(ctx, "key", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "3", quote.ID)
+}
+
+func TestQuoteCacheNegativeCaching(t *testing.T) {
+	cache, fake := newTestQuoteCache()
+	ctx := context.Background()
+	var calls atomic.Int32
+	noRoute := func(ctx context.Context) (*Quote, error) {
+		calls.Add(1)
+		return nil, ErrNoRoutes
+	}
+
+	_, err := cache.Get(ctx, "key", time.Minute, noRoute)
+	assert.ErrorIs(t, err, ErrNoRoutes)
+	_, err = cache.Get(ctx, "key", time.Minute, noRoute)
+	assert.ErrorIs(t, err, ErrNoRoutes)
+	assert.Equal(t, int32(1), calls.Load())
+
+	fake.Advance(11 * time.Second)
+	_, err = cache.Get(ctx, "key", time.Minute, noRoute)
+	assert.ErrorIs(t, err, ErrNoRoutes)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestQuoteCacheDoesNotCacheErrors(t *testing.T) {
+	cache, _ := newTestQuoteCache()
+	ctx := context.Background()
+	var calls atomic.Int32
+	failing := func(ctx context.Context) (*Quote, error) {
+		calls.Add(1)
+		return nil, errors.New("provider unavailable")
+	}
+
+	_, err := cache.Get(ctx, "key", time.Minute, failing)
+	assert.Error(t, err)
+	_, err = cache.Get(ctx, "key", time.Minute, failing)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Zero(t, cache.Size())
+}
+
+func TestQuoteCacheCallerCancellation(t *testing.T) {
+	cache, _ := newTestQuoteCache()
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (*Quote, error) {
+		<-release
+		return &Quote{ID: "q1"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.Get(ctx, "key", time.Minute, fetch)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The fetch carries on for the next caller
+	close(release)
+	quote, err := cache.Get(context.Background(), "key", time.Minute, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "q1", quote.ID)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/clock"
 )
 
 // OneInchClient implements SwapClient for 1inch API
@@ -15,6 +16,7 @@ type OneInchClient struct {
 	httpClient clients.HTTPClient
 	baseURL    string
 	apiKey     string
+	clock      clock.Clock
 }
 
 // 1inch API types
@@ -39,7 +41,7 @@ type oneInchQuoteResponse struct {
 	ToToken           oneInchToken    `json:"toToken"`
 	ToTokenAmount     string          `json:"toTokenAmount"`
 	FromTokenAmount   string          `json:"fromTokenAmount"`
-	Protocols         [][]oneInchProtocolEntry `json:"protocols"`
+	Protocols         [][][]oneInchProtocolEntry `json:"protocols"` // routes, their hops, and the split of each hop
 	EstimatedGas      int             `json:"estimatedGas"`
 	Tx                oneInchTx       `json:"tx,omitempty"`
 }
@@ -54,7 +56,7 @@ type oneInchToken struct {
 
 type oneInchProtocolEntry struct {
 	Name         string                `json:"name"`
-	Part         float64               `json:"part"`
+	Part         float64               `json:"part"` // percentage of the hop
 	FromTokenAddress string            `json:"fromTokenAddress"`
 	ToTokenAddress   string            `json:"toTokenAddress"`
 }
@@ -106,6 +108,7 @@ func NewOneInchClient(config clients.ClientConfig) *OneInchClient {
 		httpClient: httpClient,
 		baseURL:    config.BaseURL,
 		apiKey:     config.APIKey,
+		clock:      clock.System,
 	}
 }
 
@@ -135,7 +138,8 @@ func (c *OneInchClient) GetQuote(ctx context.Context, req clients.QuoteRequest)
 		q.Add("fromAddress", req.UserAddress)
 	}
 	if req.Slippage > 0 {
-		q.Add("slippage", fmt.Sprintf("%.1f", req.Slippage))
+		// 1inch takes slippage as a percentage, requests carry a fraction
+		q.Add("slippage", fmt.Sprintf("%.1f", req.Slippage*100))
 	}
 	httpReq.URL.RawQuery = q.Encode()
 
@@ -268,7 +272,7 @@ func (c *OneInchClient) isChainSupported(chainID int) bool {
 // convertToUnifiedQuote converts 1inch response to unified quote format
 func (c *OneInchClient) convertToUnifiedQuote(oneInchResp oneInchQuoteResponse, req clients.QuoteRequest) *clients.Quote {
 	quote := &clients.Quote{
-		ID:          fmt.Sprintf("1inch-%d", time.Now().Unix()),
+		ID:          fmt.Sprintf("1inch-%d", c.clock.Now().Unix()),
 		Type:        "swap",
 		Provider:    "1inch",
 		FromChainID: req.FromChainID,
@@ -292,7 +296,7 @@ func (c *OneInchClient) convertToUnifiedQuote(oneInchResp oneInchQuoteResponse,
 		FromAmount:   oneInchResp.FromTokenAmount,
 		ToAmount:     oneInchResp.ToTokenAmount,
 		EstimatedGas: strconv.Itoa(oneInchResp.EstimatedGas),
-		ExpiresAt:    time.Now().Add(60 * time.Second), // 1inch quotes expire after 60 seconds
+		ExpiresAt:    c.clock.Now().Add(60 * time.Second), // 1inch quotes expire after 60 seconds
 	}
 
 	// Calculate exchange rate
@@ -319,24 +323,26 @@ func (c *OneInchClient) convertToUnifiedQuote(oneInchResp oneInchQuoteResponse,
 
 	// Convert protocols to route steps
 	var routeSteps []clients.RouteStep
-	for _, protocolGroup := range oneInchResp.Protocols {
-		for _, protocol := range protocolGroup {
-			if protocol.Part > 0 {
-				routeSteps = append(routeSteps, clients.RouteStep{
-					Protocol:     protocol.Name,
-					Type:         "swap",
-					FromAmount:   oneInchResp.FromTokenAmount,
-					ToAmount:     oneInchResp.ToTokenAmount,
-					Percentage:   fmt.Sprintf("%.2f", protocol.Part*100),
-					FromToken: clients.Token{
-						Address: protocol.FromTokenAddress,
-						ChainID: req.FromChainID,
-					},
-					ToToken: clients.Token{
-						Address: protocol.ToTokenAddress,
-						ChainID: req.FromChainID,
-					},
-				})
+	for _, route := range oneInchResp.Protocols {
+		for _, hop := range route {
+			for _, protocol := range hop {
+				if protocol.Part > 0 {
+					routeSteps = append(routeSteps, clients.RouteStep{
+						Protocol:     protocol.Name,
+						Type:         "swap",
+						FromAmount:   oneInchResp.FromTokenAmount,
+						ToAmount:     oneInchResp.ToTokenAmount,
+						Percentage:   fmt.Sprintf("%.2f", protocol.Part),
+						FromToken: clients.Token{
+							Address: protocol.FromTokenAddress,
+							ChainID: req.FromChainID,
+						},
+						ToToken: clients.Token{
+							Address: protocol.ToTokenAddress,
+							ChainID: req.FromChainID,
+						},
+					})
+				}
 			}
 		}
 	}
@@ -0,0 +1,88 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneInchClientGetQuote(t *testing.T) {
+	server := vcr.Replay(t, "testdata/oneinch/quote.json")
+	client := NewOneInchClient(testConfig(server.URL))
+
+	quote, err := client.GetQuote(context.Background(), usdcToWETH)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1inch", quote.Provider)
+	assert.Equal(t, "swap", quote.Type)
+	assert.Equal(t, clients.Token{
+		Address:  "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		Symbol:   "USDC",
+		Name:     "USD Coin",
+		Decimals: 6,
+		ChainID:  "1",
+		LogoURI:  "https://tokens.1inch.io/0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48.png",
+	}, quote.FromToken)
+	assert.Equal(t, "WETH", quote.ToToken.Symbol)
+	assert.Equal(t, "2500000000", quote.FromAmount)
+	assert.Equal(t, "733512964201385129", quote.ToAmount)
+	assert.Equal(t, "186432", quote.EstimatedGas)
+	assert.NotEmpty(t, quote.ExchangeRate)
+	assert.Nil(t, quote.TransactionData, "quotes carry no transaction")
+
+	require.Len(t, quote.Route, 2)
+	assert.Equal(t, "UNISWAP_V3", quote.Route[0].Protocol)
+	assert.Equal(t, "80.00", quote.Route[0].Percentage)
+	assert.Equal(t, "CURVE_V2", quote.Route[1].Protocol)
+	assert.Equal(t, "20.00", quote.Route[1].Percentage)
+	assert.Equal(t, "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", quote.Route[1].ToToken.Address)
+}
+
+func TestOneInchClientGetQuoteErrors(t *testing.T) {
+	server := vcr.Replay(t, "testdata/oneinch/quote_errors.json")
+	client := NewOneInchClient(testConfig(server.URL))
+	ctx := context.Background()
+	req := usdcToWETH
+	req.FromChainID = "137"
+
+	_, err := client.GetQuote(ctx, req)
+	var apiErr *clients.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, "insufficient liquidity", apiErr.Message)
+
+	_, err = client.GetQuote(ctx, req)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 401, apiErr.StatusCode)
+	assert.Equal(t, "Unauthorized", apiErr.Message)
+
+	req.FromChainID = "not-a-chain"
+	_, err = client.GetQuote(ctx, req)
+	assert.ErrorContains(t, err, "invalid chain ID")
+}
+
+func TestOneInchClientTokensAndHealth(t *testing.T) {
+	server := vcr.Replay(t, "testdata/oneinch/tokens_health.json")
+	client := NewOneInchClient(testConfig(server.URL))
+	ctx := context.Background()
+
+	tokens, err := client.GetSupportedTokens(ctx, "10")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, clients.Token{
+		Address:  "0x0b2c639c533813f4aa9d7837caf62653d097ff85",
+		Symbol:   "USDC",
+		Name:     "USD Coin",
+		Decimals: 6,
+		ChainID:  "10",
+		LogoURI:  "https://tokens.1inch.io/0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48.png",
+	}, tokens[0])
+
+	assert.True(t, client.IsHealthy(ctx))
+	assert.False(t, client.IsHealthy(ctx))
+}
@@ -9,13 +9,15 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/clock"
 )
 
 // ZeroXClient implements SwapClient for 0x API
 type ZeroXClient struct {
 	httpClient clients.HTTPClient
 	baseURL    string
-	apiKey     string
+	apiKey     *clients.APIKey
+	clock      clock.Clock
 }
 
 // 0x API types
@@ -109,7 +111,8 @@ func NewZeroXClient(config clients.ClientConfig) *ZeroXClient {
 	return &ZeroXClient{
 		httpClient: httpClient,
 		baseURL:    config.BaseURL,
-		apiKey:     config.APIKey,
+		apiKey:     clients.NewAPIKey(config.APIKey),
+		clock:      clock.System,
 	}
 }
 
@@ -147,8 +150,8 @@ func (c *ZeroXClient) GetQuote(ctx context.Context, req clients.QuoteRequest) (*
 	httpReq.URL.RawQuery = q.Encode()
 
 	// Add headers
-	if c.apiKey != "" {
-		httpReq.Header.Set("0x-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("0x-api-key", apiKey)
 	}
 	httpReq.Header.Set("Accept", "application/json")
 
@@ -184,8 +187,8 @@ func (c *ZeroXClient) GetSupportedTokens(ctx context.Context, chainID string) ([
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	if c.apiKey != "" {
-		httpReq.Header.Set("0x-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("0x-api-key", apiKey)
 	}
 	httpReq.Header.Set("Accept", "application/json")
 
@@ -219,6 +222,11 @@ func (c *ZeroXClient) GetProviderName() string {
 	return "0x"
 }
 
+// SetAPIKey rotates the API key used for subsequent requests
+func (c *ZeroXClient) SetAPIKey(key string) {
+	c.apiKey.Set(key)
+}
+
 // IsHealthy checks if the provider API is responding
 func (c *ZeroXClient) IsHealthy(ctx context.Context) bool {
 	// Use Ethereum mainnet for health check
@@ -228,8 +236,8 @@ func (c *ZeroXClient) IsHealthy(ctx context.Context) bool {
 		return false
 	}
 
-	if c.apiKey != "" {
-		httpReq.Header.Set("0x-api-key", c.apiKey)
+	if apiKey := c.apiKey.Get(); apiKey != "" {
+		httpReq.Header.Set("0x-api-key", apiKey)
 	}
 
 	resp, err := c.httpClient.Do(httpReq)
@@ -287,7 +295,7 @@ func (c *ZeroXClient) convertToUnifiedQuote(zeroXResp zeroXQuoteResponse, req cl
 		PriceImpact:   priceImpact,
 		EstimatedGas:  zeroXResp.EstimatedGas,
 		GasPriceWei:   zeroXResp.GasPrice,
-		ExpiresAt:     time.Now().Add(30 * time.Second), // 0x quotes expire quickly
+		ExpiresAt:     c.clock.Now().Add(30 * time.Second), // 0x quotes expire quickly
 	}
 
 	// Add transaction data
@@ -0,0 +1,111 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig points a client at a replay server, without retries so each recorded
+// response is seen once
+func testConfig(baseURL string) clients.ClientConfig {
+	return clients.ClientConfig{
+		BaseURL:    baseURL,
+		APIKey:     "test-key",
+		Timeout:    5 * time.Second,
+		RetryDelay: time.Millisecond,
+		RateLimit:  clients.RateLimitConfig{RequestsPerSecond: 100, BurstSize: 10},
+	}
+}
+
+var usdcToWETH = clients.QuoteRequest{
+	FromChainID: "1",
+	FromToken:   "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	ToToken:     "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+	Amount:      "2500000000",
+	UserAddress: "0x8ba1f109551bD432803012645Ac136ddd64DBA72",
+	Slippage:    0.01,
+}
+
+func TestZeroXClientGetQuote(t *testing.T) {
+	server := vcr.Replay(t, "testdata/zerox/quote.json")
+	config := testConfig(server.URL)
+	config.MaxRetries = 1
+	client := NewZeroXClient(config)
+
+	// The first attempt is rate limited and retried
+	quote, err := client.GetQuote(context.Background(), usdcToWETH)
+	require.NoError(t, err)
+
+	assert.Equal(t, "0x5d8e2c1f9a-1718041234", quote.ID)
+	assert.Equal(t, "swap", quote.Type)
+	assert.Equal(t, "0x", quote.Provider)
+	assert.Equal(t, "1", quote.ToChainID)
+	assert.Equal(t, "2500000000", quote.FromAmount)
+	assert.Equal(t, "732881781460755000", quote.ToAmount)
+	assert.Equal(t, "0.000293152712584302", quote.ExchangeRate)
+	assert.InDelta(t, 0.0412, quote.PriceImpact, 1e-9)
+	assert.Equal(t, "212000", quote.EstimatedGas)
+	assert.Equal(t, "21450000000", quote.GasPriceWei)
+	assert.Equal(t, clients.Token{Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", ChainID: "1"}, quote.FromToken)
+	assert.Equal(t, clients.Token{Address: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", ChainID: "1"}, quote.ToToken)
+
+	require.NotNil(t, quote.TransactionData)
+	assert.Equal(t, "0xdef1c0ded9bec7f1a1670819833240f027b25eff", quote.TransactionData.To)
+	assert.Equal(t, "212000", quote.TransactionData.GasLimit)
+
+	require.Len(t, quote.Route, 2, "sources with no share are left out")
+	assert.Equal(t, "Uniswap_V3", quote.Route[0].Protocol)
+	assert.Equal(t, "0.7", quote.Route[0].Percentage)
+	assert.Equal(t, "Curve", quote.Route[1].Protocol)
+	assert.Empty(t, quote.Fees, "no protocol fee")
+}
+
+func TestZeroXClientGetQuoteErrors(t *testing.T) {
+	server := vcr.Replay(t, "testdata/zerox/quote_errors.json")
+	client := NewZeroXClient(testConfig(server.URL))
+	ctx := context.Background()
+	req := usdcToWETH
+	req.FromChainID = "137"
+
+	_, err := client.GetQuote(ctx, req)
+	var apiErr *clients.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, "100", apiErr.Code)
+	assert.Equal(t, "Validation Failed; sellAmount: INSUFFICIENT_ASSET_LIQUIDITY", apiErr.Message)
+
+	_, err = client.GetQuote(ctx, req)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 500, apiErr.StatusCode)
+	assert.EqualError(t, apiErr, "API error [102]: Internal Server Error")
+
+	// Chains 0x doesn't serve never reach the API
+	req.FromChainID = "324"
+	_, err = client.GetQuote(ctx, req)
+	assert.EqualError(t, err, "unsupported chain ID: 324")
+}
+
+func TestZeroXClientGetSupportedTokens(t *testing.T) {
+	server := vcr.Replay(t, "testdata/zerox/tokens.json")
+	client := NewZeroXClient(testConfig(server.URL))
+
+	tokens, err := client.GetSupportedTokens(context.Background(), "42161")
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, clients.Token{
+		Address:  "0xaf88d065e77c8cc2239327c5edb3a432268e5831",
+		Symbol:   "USDC",
+		Name:     "USD Coin",
+		Decimals: 6,
+		ChainID:  "42161",
+		LogoURI:  "https://raw.githubusercontent.com/0xProject/0x-token-list/main/images/usdc.png",
+	}, tokens[0])
+	assert.Equal(t, 18, tokens[1].Decimals)
+}
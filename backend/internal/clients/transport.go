@@ -0,0 +1,52 @@
+package clients
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// newTransport builds the round tripper for a client: tuned as configured, through its
+// proxy if it has one, and limited to its allowed hosts. Requests otherwise go through
+// http.DefaultTransport, which mock provider mode replaces; mocked clients aren't
+// tuned and don't use their proxy.
+func newTransport(config ClientConfig) http.RoundTripper {
+	base := http.DefaultTransport
+	if defaults, ok := base.(*http.Transport); ok && (config.ProxyURL != "" || !config.Transport.IsZero()) {
+		transport := defaults.Clone()
+		tuneTransport(transport, config.Transport)
+		if config.ProxyURL != "" {
+			proxyURL, err := ParseProxyURL(config.ProxyURL)
+			// A bad proxy URL fails each request rather than bypassing the proxy
+			transport.Proxy = func(*http.Request) (*url.URL, error) { return proxyURL, err }
+		}
+		base = transport
+	}
+	if len(config.AllowedHosts) > 0 {
+		base = &egressTransport{base: base, allowed: config.AllowedHosts}
+	}
+	return base
+}
+
+// tuneTransport applies the config's non-zero settings to transport
+func tuneTransport(transport *http.Transport, config TransportConfig) {
+	if config.ConnectTimeout > 0 {
+		dialer := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if config.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+	if config.MaxIdleConns > 0 {
+		// Each client calls a single provider, so its idle connections all go to one host
+		transport.MaxIdleConns = config.MaxIdleConns
+		transport.MaxIdleConnsPerHost = config.MaxIdleConns
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+}
@@ -0,0 +1,44 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuneTransport(t *testing.T) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tuneTransport(transport, DefaultQuoteTransport)
+	assert.Equal(t, DefaultQuoteTransport.TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	assert.Equal(t, DefaultQuoteTransport.ResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	assert.Equal(t, DefaultQuoteTransport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+
+	// Zero settings leave the defaults alone
+	untouched := http.DefaultTransport.(*http.Transport).Clone()
+	tuneTransport(untouched, TransportConfig{})
+	assert.Equal(t, http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout, untouched.TLSHandshakeTimeout)
+	assert.Zero(t, untouched.ResponseHeaderTimeout)
+}
+
+func TestBaseHTTPClientResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := ClientConfig{
+		Timeout:   5 * time.Second,
+		RateLimit: RateLimitConfig{RequestsPerSecond: 100, BurstSize: 10},
+		Transport: TransportConfig{ResponseHeaderTimeout: 50 * time.Millisecond},
+	}
+	start := time.Now()
+	_, err := NewBaseHTTPClient(config).Get(server.URL)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second, "gives up on the response header, well before the client timeout")
+}
@@ -1,6 +1,9 @@
 package clients
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -15,6 +18,11 @@ type QuoteRequest struct {
 	Amount      string  `json:"amount"`
 	UserAddress string  `json:"userAddress"`
 	Slippage    float64 `json:"slippage,omitempty"` // Optional, defaults to 0.5%
+	// AllowMultiTx accepts routes that need more than one transaction, where the provider supports them
+	AllowMultiTx bool `json:"allowMultiTx,omitempty"`
+	// FromAmountForGas asks bridges for a refuel leg delivering this much of Amount as the
+	// destination chain's gas token. Socket picks the amount itself and only uses it as a flag.
+	FromAmountForGas string `json:"fromAmountForGas,omitempty"`
 }
 
 // Quote represents a unified response for quotes
@@ -38,6 +46,19 @@ type Quote struct {
 	TransactionData   *TransactionData       `json:"transactionData,omitempty"`
 	ExpiresAt         time.Time              `json:"expiresAt"`
 	AdditionalData    map[string]interface{} `json:"additionalData,omitempty"`
+	// Refuel is the leg delivering the destination chain's gas token, when one was asked for
+	// and the provider included it
+	Refuel *Refuel `json:"refuel,omitempty"`
+}
+
+// Refuel is a bridge leg swapping part of the transfer into the destination chain's gas
+// token, so the recipient can pay for transactions there
+type Refuel struct {
+	Protocol   string `json:"protocol"`
+	FromToken  Token  `json:"fromToken"`
+	FromAmount string `json:"fromAmount"`
+	ToToken    Token  `json:"toToken"`
+	ToAmount   string `json:"toAmount"`
 }
 
 // Token represents token information
@@ -81,6 +102,48 @@ type TransactionData struct {
 	ChainID  string `json:"chainId"`
 }
 
+// Bridge transfer statuses
+const (
+	TransferStatusPending  = "pending"
+	TransferStatusDone     = "done"
+	TransferStatusFailed   = "failed"
+	TransferStatusNotFound = "not_found"
+)
+
+// TransferStatusRequest identifies a bridge transfer by its source transaction
+type TransferStatusRequest struct {
+	FromChainID string `json:"fromChainId"`
+	ToChainID   string `json:"toChainId"`
+	TxHash      string `json:"txHash"`
+}
+
+// TransferStatus represents the progress of a bridge transfer
+type TransferStatus struct {
+	Provider           string `json:"provider"`
+	Status             string `json:"status"` // "pending", "done", "failed" or "not_found"
+	Bridge             string `json:"bridge,omitempty"`
+	SourceTxHash       string `json:"sourceTxHash"`
+	DestinationChainID string `json:"destinationChainId,omitempty"`
+	DestinationTxHash  string `json:"destinationTxHash,omitempty"`
+}
+
+// ErrNoRoutes is returned when a provider has no route for the requested transfer
+var ErrNoRoutes = errors.New("no routes found")
+
+// APIError is a non-200 response from a provider
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("API error [%s]: %s", e.Code, e.Message)
+}
+
 // ErrorResponse represents an error from external APIs
 type ErrorResponse struct {
 	Code    string `json:"code"`
@@ -97,14 +160,22 @@ type CacheKey struct {
 	ToToken     string
 	Amount      string
 	UserAddress string
+	// Variant tells apart quotes for the same trade fetched with different parameters
+	Variant string
 }
 
 // String returns a string representation of the cache key
 func (c CacheKey) String() string {
+	var key string
 	if c.ToChain == "" {
 		// Swap key
-		return c.Provider + ":" + c.FromChain + ":" + c.FromToken + ":" + c.ToToken + ":" + c.Amount + ":" + c.UserAddress
+		key = c.Provider + ":" + c.FromChain + ":" + c.FromToken + ":" + c.ToToken + ":" + c.Amount + ":" + c.UserAddress
+	} else {
+		// Bridge key
+		key = c.Provider + ":" + c.FromChain + ":" + c.ToChain + ":" + c.FromToken + ":" + c.ToToken + ":" + c.Amount + ":" + c.UserAddress
+	}
+	if c.Variant != "" {
+		key += ":" + c.Variant
 	}
-	// Bridge key
-	return c.Provider + ":" + c.FromChain + ":" + c.ToChain + ":" + c.FromToken + ":" + c.ToToken + ":" + c.Amount + ":" + c.UserAddress
+	return key
 }
\ No newline at end of file
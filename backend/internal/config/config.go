@@ -4,9 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/attestation"
+	"github.com/defi-dashboard/backend/pkg/analytics"
+	"github.com/defi-dashboard/backend/pkg/billing"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/defi-dashboard/backend/pkg/netguard"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -15,6 +23,16 @@ type Config struct {
 	// Server
 	Port     string
 	LogLevel string
+	// LogDebugSampleRate keeps one in every n high-volume debug messages
+	LogDebugSampleRate int
+	// CaptureFailedRequests keeps the redacted bodies of the last RequestCaptureSize
+	// failed API requests for admins
+	CaptureFailedRequests bool
+	RequestCaptureSize    int
+	// ProviderCallLog logs calls to upstream providers made for API requests;
+	// ProviderCallLogAll also logs those made by scheduled jobs
+	ProviderCallLog    bool
+	ProviderCallLogAll bool
 
 	// Database
 	DatabaseURL string
@@ -26,6 +44,41 @@ type Config struct {
 	// API
 	APIVersion   string
 	AllowOrigins string
+	// APIV1Sunset is the date (YYYY-MM-DD) deprecated /v1 response shapes are removed,
+	// announced in their Sunset header; unset announces none
+	APIV1Sunset string
+	// PublicURL is the API's externally reachable base URL, used in links sent to users
+	PublicURL string
+	// RateLimitPerMinute caps the requests per minute from one client IP
+	RateLimitPerMinute int
+	// Seconds clients may reuse ETag'd responses before revalidating, per route group
+	CacheMaxAgePortfolio int
+	CacheMaxAgePools     int
+	CacheMaxAgeAlerts    int
+	// Responses of at least this many bytes are compressed; bodies are capped at
+	// MaxBodySize server-wide, ImportBodyLimit for transaction imports and
+	// WebhookBodyLimit for inbound provider webhooks
+	CompressMinSize  int
+	MaxBodySize      int
+	ImportBodyLimit  int
+	WebhookBodyLimit int
+
+	// Object storage for exports, reports and imports
+	StorageBackend         string // local, s3, minio or gcs
+	StorageLocalDir        string
+	StorageBucket          string
+	StorageEndpoint        string
+	StorageRegion          string
+	StorageAccessKeyID     string
+	StorageSecretAccessKey string
+	StoragePathStyle       bool
+	// StorageSigningKey signs local download URLs; defaults to JWT_SECRET
+	StorageSigningKey      string
+	StorageSignedURLTTL    int // seconds
+	// Retention per upload kind in days; 0 keeps uploads until deleted
+	StorageRetentionExportDays int
+	StorageRetentionReportDays int
+	StorageRetentionImportDays int
 
 	// External Services
 	AlchemyAPIKey   string
@@ -33,6 +86,14 @@ type Config struct {
 	EtherscanAPIKey string
 	CoinGeckoAPIKey string
 	DefiLlamaEnabled bool
+	BeaconchainAPIKey string
+	// BitcoinAPIURL is an Esplora HTTP API, e.g. Blockstream's or a self-hosted electrs
+	BitcoinAPIURL string
+	// Alchemy Notify webhooks, "chainID:webhookID:signingKey,..."; unset disables the
+	// webhook endpoint. AlchemyNotifyToken lets the worker keep each webhook's addresses
+	// in step with the wallets tracked; without it they're managed in Alchemy's dashboard.
+	AlchemyWebhooks    string
+	AlchemyNotifyToken string
 
 	// Bridge Clients
 	LiFiAPIKey   string
@@ -52,9 +113,127 @@ type Config struct {
 	ExternalAPIRetryDelay  int
 	ExternalAPIRateLimitRPS int
 	ExternalAPIRateLimitBurst int
+	// Connection tuning for the bridge/swap quote providers; times in milliseconds, 0
+	// keeps Go's default
+	QuoteConnectTimeout        int
+	QuoteTLSHandshakeTimeout   int
+	QuoteResponseHeaderTimeout int
+	QuoteMaxIdleConns          int
+
+	// Outbound proxy for the provider clients: OUTBOUND_PROXY_URL applies to all of them
+	// unless a client has its own *_PROXY_URL
+	OutboundProxyURL string
+	LiFiProxyURL     string
+	SocketProxyURL   string
+	ZeroXProxyURL    string
+	OneInchProxyURL  string
+	// EgressAllowedHosts are the only hosts provider clients may call, comma-separated;
+	// "*.example.com" also allows its subdomains. Unset allows any host.
+	EgressAllowedHosts string
+
+	// Worker RPC: the worker listens on WorkerRPCAddr and the API reaches it at
+	// WorkerRPCURL, both authenticating with WorkerRPCToken. Unset disables it.
+	WorkerRPCAddr  string
+	WorkerRPCURL   string
+	WorkerRPCToken string
+
+	// TelegramBotToken enables Telegram alert notifications through that bot
+	TelegramBotToken string
+	// WebhookAllowInsecure lets alert webhooks use http and private addresses, for
+	// development against receivers running locally. Never set it in production.
+	WebhookAllowInsecure bool
+
+	// Email provider (log, ses, sendgrid or smtp) and the address email comes from.
+	// SES uses the AWS credentials below unless EMAIL_SES_* are set.
+	EmailProvider           string
+	EmailFrom               string
+	EmailSESRegion          string
+	EmailSESAccessKeyID     string
+	EmailSESSecretAccessKey string
+	SendGridAPIKey          string
+	SMTPHost                string
+	SMTPPort                int
+	SMTPUsername            string
+	SMTPPassword            string
+	// EmailWebhookSecret authenticates the provider's bounce and complaint webhooks,
+	// passed as their token query parameter. Unset disables the webhooks.
+	EmailWebhookSecret string
+
+	// Stripe billing for the pro tier. StripeSecretKey enables checkout and
+	// StripeWebhookSecret the webhook endpoint; BillingGracePeriodHours is how long a
+	// subscriber whose renewal failed stays on pro.
+	StripeSecretKey         string
+	StripeWebhookSecret     string
+	StripeProPriceID        string
+	BillingSuccessURL       string
+	BillingCancelURL        string
+	BillingGracePeriodHours int
+
+	// Anonymous usage analytics. AnalyticsSink is "", log, segment or clickhouse;
+	// AnalyticsSalt keys the hash users are identified by.
+	AnalyticsSink   string
+	AnalyticsSalt   string
+	SegmentWriteKey string
+	ClickHouseURL   string
+	ClickHouseTable string
+
+	// AlertMaxDataAgeMinutes is how old prices may be before the alert evaluator skips
+	// the alerts that read them rather than fire on stale data
+	AlertMaxDataAgeMinutes int
+	// AlertTargetGoneHours is how long a pool or token may go without updates before
+	// alerts on it are expired as delisted
+	AlertTargetGoneHours int
+	// AlertHistoryRetentionDays is how long triggers are kept before their monthly
+	// partition is dropped; 0 keeps them forever
+	AlertHistoryRetentionDays int
 
 	// Redis (optional)
 	RedisURL string
+
+	// Confirmations required per chain before a transaction is final, e.g. "1:12,137:128"
+	FinalityThresholds string
+
+	// Calls per JSON-RPC batch request, by default and per chain, e.g. "80002:10"
+	RPCBatchSize  int
+	RPCBatchSizes string
+
+	// TestnetMode counts testnet wallets and chains towards totals by default, for
+	// environments run against testnets
+	TestnetMode bool
+
+	// MockProviders answers external provider calls with deterministic fake data, so
+	// the API and worker run offline
+	MockProviders bool
+
+	// Encryption key for secrets stored at rest (32 bytes, hex or base64)
+	EncryptionKey string
+	// EncryptionPreviousKeys are comma-separated keys rotated out of ENCRYPTION_KEY,
+	// kept to open secrets until they're rotated onto the current key
+	EncryptionPreviousKeys string
+	// AttestationSigningKey is the Ed25519 seed portfolio attestations are signed with
+	// (32 bytes, hex or base64); unset disables attestations
+	AttestationSigningKey string
+
+	// Secrets management (env, vault or aws)
+	SecretsProvider        string
+	SecretsRefreshInterval int // seconds
+	VaultAddr              string
+	VaultToken             string
+	VaultSecretPath        string
+	AWSRegion              string
+	AWSSecretID            string
+	AWSAccessKeyID         string
+	AWSSecretAccessKey     string
+	AWSSessionToken        string
+
+	// How often provider policies set by admins are re-read, in seconds
+	ProviderPolicyReloadInterval int
+
+	// How often log settings set by admins are re-read, in seconds
+	LogSettingsReloadInterval int
+
+	// Database queries taking at least this long are logged, in milliseconds (0 disables)
+	DBSlowQueryThreshold int
 }
 
 func Load() (*Config, error) {
@@ -86,10 +265,37 @@ func Load() (*Config, error) {
 	// Set defaults
 	viper.SetDefault("PORT", "3000")
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_DEBUG_SAMPLE_RATE", 100)
+	viper.SetDefault("CAPTURE_FAILED_REQUESTS", false)
+	viper.SetDefault("REQUEST_CAPTURE_SIZE", 500)
+	viper.SetDefault("PROVIDER_CALL_LOG", true)
+	viper.SetDefault("PROVIDER_CALL_LOG_ALL", false)
 	viper.SetDefault("API_VERSION", "v1")
 	viper.SetDefault("JWT_EXPIRY", 24)
 	viper.SetDefault("ALLOW_ORIGINS", "*")
+	viper.SetDefault("PUBLIC_URL", "http://localhost:3000")
+	viper.SetDefault("RATE_LIMIT_PER_MINUTE", 100)
+	viper.SetDefault("RPC_BATCH_SIZE", blockchain.DefaultRPCBatchSize)
+	viper.SetDefault("CACHE_MAX_AGE_PORTFOLIO", 15)
+	viper.SetDefault("CACHE_MAX_AGE_POOLS", 60)
+	viper.SetDefault("CACHE_MAX_AGE_ALERTS", 0)
+	viper.SetDefault("COMPRESS_MIN_SIZE", 1024)
+	viper.SetDefault("MAX_BODY_SIZE", 4<<20)
+	viper.SetDefault("IMPORT_BODY_LIMIT", 3<<20)
+	viper.SetDefault("WEBHOOK_BODY_LIMIT", 512<<10)
+	viper.SetDefault("BILLING_GRACE_PERIOD_HOURS", 7*24)
+	viper.SetDefault("CLICKHOUSE_TABLE", "events")
+	viper.SetDefault("ALERT_MAX_DATA_AGE_MINUTES", 30)
+	viper.SetDefault("ALERT_TARGET_GONE_HOURS", 72)
+	viper.SetDefault("ALERT_HISTORY_RETENTION_DAYS", 365)
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "./data/storage")
+	viper.SetDefault("STORAGE_SIGNED_URL_TTL", 3600)
+	viper.SetDefault("STORAGE_RETENTION_EXPORT_DAYS", 7)
+	viper.SetDefault("STORAGE_RETENTION_REPORT_DAYS", 0)
+	viper.SetDefault("STORAGE_RETENTION_IMPORT_DAYS", 30)
 	viper.SetDefault("DEFILLAMA_ENABLED", true)
+	viper.SetDefault("BITCOIN_API_URL", "https://blockstream.info/api")
 	
 	// External API defaults
 	viper.SetDefault("LIFI_BASE_URL", "https://li.quest/v1")
@@ -101,20 +307,78 @@ func Load() (*Config, error) {
 	viper.SetDefault("EXTERNAL_API_RETRY_DELAY", 1000)
 	viper.SetDefault("EXTERNAL_API_RATE_LIMIT_RPS", 10)
 	viper.SetDefault("EXTERNAL_API_RATE_LIMIT_BURST", 20)
+	viper.SetDefault("QUOTE_CONNECT_TIMEOUT", int(clients.DefaultQuoteTransport.ConnectTimeout/time.Millisecond))
+	viper.SetDefault("QUOTE_TLS_HANDSHAKE_TIMEOUT", int(clients.DefaultQuoteTransport.TLSHandshakeTimeout/time.Millisecond))
+	viper.SetDefault("QUOTE_RESPONSE_HEADER_TIMEOUT", int(clients.DefaultQuoteTransport.ResponseHeaderTimeout/time.Millisecond))
+	viper.SetDefault("QUOTE_MAX_IDLE_CONNS", clients.DefaultQuoteTransport.MaxIdleConns)
+	viper.SetDefault("SECRETS_PROVIDER", "env")
+	viper.SetDefault("SECRETS_REFRESH_INTERVAL", 300)
+	viper.SetDefault("VAULT_SECRET_PATH", "secret/defi-dashboard")
+	viper.SetDefault("PROVIDER_POLICY_RELOAD_INTERVAL", 30)
+	viper.SetDefault("LOG_SETTINGS_RELOAD_INTERVAL", 30)
+	viper.SetDefault("DB_SLOW_QUERY_THRESHOLD", 200)
+	viper.SetDefault("EMAIL_PROVIDER", "log")
+	viper.SetDefault("SMTP_PORT", 587)
 
 	cfg := &Config{
 		Port:            viper.GetString("PORT"),
 		LogLevel:        viper.GetString("LOG_LEVEL"),
+		LogDebugSampleRate: viper.GetInt("LOG_DEBUG_SAMPLE_RATE"),
+		CaptureFailedRequests: viper.GetBool("CAPTURE_FAILED_REQUESTS"),
+		RequestCaptureSize:    viper.GetInt("REQUEST_CAPTURE_SIZE"),
+		ProviderCallLog:       viper.GetBool("PROVIDER_CALL_LOG"),
+		ProviderCallLogAll:    viper.GetBool("PROVIDER_CALL_LOG_ALL"),
 		DatabaseURL:     viper.GetString("DATABASE_URL"),
 		JWTSecret:       viper.GetString("JWT_SECRET"),
 		JWTExpiry:       viper.GetInt("JWT_EXPIRY"),
 		APIVersion:      viper.GetString("API_VERSION"),
 		AllowOrigins:    viper.GetString("ALLOW_ORIGINS"),
+		APIV1Sunset:     viper.GetString("API_V1_SUNSET"),
+		PublicURL:       viper.GetString("PUBLIC_URL"),
+		RateLimitPerMinute: viper.GetInt("RATE_LIMIT_PER_MINUTE"),
+		CacheMaxAgePortfolio: viper.GetInt("CACHE_MAX_AGE_PORTFOLIO"),
+		CacheMaxAgePools:     viper.GetInt("CACHE_MAX_AGE_POOLS"),
+		CacheMaxAgeAlerts:    viper.GetInt("CACHE_MAX_AGE_ALERTS"),
+		CompressMinSize:      viper.GetInt("COMPRESS_MIN_SIZE"),
+		MaxBodySize:          viper.GetInt("MAX_BODY_SIZE"),
+		ImportBodyLimit:      viper.GetInt("IMPORT_BODY_LIMIT"),
+		WebhookBodyLimit:     viper.GetInt("WEBHOOK_BODY_LIMIT"),
+		StorageBackend:         viper.GetString("STORAGE_BACKEND"),
+		StorageLocalDir:        viper.GetString("STORAGE_LOCAL_DIR"),
+		StorageBucket:          viper.GetString("STORAGE_BUCKET"),
+		StorageEndpoint:        viper.GetString("STORAGE_ENDPOINT"),
+		StorageRegion:          viper.GetString("STORAGE_REGION"),
+		StorageAccessKeyID:     viper.GetString("STORAGE_ACCESS_KEY_ID"),
+		StorageSecretAccessKey: viper.GetString("STORAGE_SECRET_ACCESS_KEY"),
+		StoragePathStyle:       viper.GetBool("STORAGE_PATH_STYLE"),
+		StorageSigningKey:      viper.GetString("STORAGE_SIGNING_KEY"),
+		StorageSignedURLTTL:    viper.GetInt("STORAGE_SIGNED_URL_TTL"),
+		StorageRetentionExportDays: viper.GetInt("STORAGE_RETENTION_EXPORT_DAYS"),
+		StorageRetentionReportDays: viper.GetInt("STORAGE_RETENTION_REPORT_DAYS"),
+		StorageRetentionImportDays: viper.GetInt("STORAGE_RETENTION_IMPORT_DAYS"),
 		AlchemyAPIKey:   viper.GetString("ALCHEMY_API_KEY"),
+		AlchemyWebhooks:    viper.GetString("ALCHEMY_WEBHOOKS"),
+		AlchemyNotifyToken: viper.GetString("ALCHEMY_NOTIFY_TOKEN"),
+		StripeSecretKey:         viper.GetString("STRIPE_SECRET_KEY"),
+		StripeWebhookSecret:     viper.GetString("STRIPE_WEBHOOK_SECRET"),
+		StripeProPriceID:        viper.GetString("STRIPE_PRO_PRICE_ID"),
+		BillingSuccessURL:       viper.GetString("BILLING_SUCCESS_URL"),
+		BillingCancelURL:        viper.GetString("BILLING_CANCEL_URL"),
+		BillingGracePeriodHours: viper.GetInt("BILLING_GRACE_PERIOD_HOURS"),
+		AnalyticsSink:           viper.GetString("ANALYTICS_SINK"),
+		AnalyticsSalt:           viper.GetString("ANALYTICS_SALT"),
+		SegmentWriteKey:         viper.GetString("SEGMENT_WRITE_KEY"),
+		ClickHouseURL:           viper.GetString("CLICKHOUSE_URL"),
+		ClickHouseTable:         viper.GetString("CLICKHOUSE_TABLE"),
+		AlertMaxDataAgeMinutes:  viper.GetInt("ALERT_MAX_DATA_AGE_MINUTES"),
+		AlertTargetGoneHours:    viper.GetInt("ALERT_TARGET_GONE_HOURS"),
+		AlertHistoryRetentionDays: viper.GetInt("ALERT_HISTORY_RETENTION_DAYS"),
 		InfuraAPIKey:    viper.GetString("INFURA_API_KEY"),
 		EtherscanAPIKey: viper.GetString("ETHERSCAN_API_KEY"),
 		CoinGeckoAPIKey: viper.GetString("COINGECKO_API_KEY"),
 		DefiLlamaEnabled: viper.GetBool("DEFILLAMA_ENABLED"),
+		BeaconchainAPIKey: viper.GetString("BEACONCHAIN_API_KEY"),
+		BitcoinAPIURL:     viper.GetString("BITCOIN_API_URL"),
 		
 		// Bridge Clients
 		LiFiAPIKey:      viper.GetString("LIFI_API_KEY"),
@@ -134,8 +398,63 @@ func Load() (*Config, error) {
 		ExternalAPIRetryDelay:     viper.GetInt("EXTERNAL_API_RETRY_DELAY"),
 		ExternalAPIRateLimitRPS:   viper.GetInt("EXTERNAL_API_RATE_LIMIT_RPS"),
 		ExternalAPIRateLimitBurst: viper.GetInt("EXTERNAL_API_RATE_LIMIT_BURST"),
+		QuoteConnectTimeout:        viper.GetInt("QUOTE_CONNECT_TIMEOUT"),
+		QuoteTLSHandshakeTimeout:   viper.GetInt("QUOTE_TLS_HANDSHAKE_TIMEOUT"),
+		QuoteResponseHeaderTimeout: viper.GetInt("QUOTE_RESPONSE_HEADER_TIMEOUT"),
+		QuoteMaxIdleConns:          viper.GetInt("QUOTE_MAX_IDLE_CONNS"),
+
+		OutboundProxyURL:   viper.GetString("OUTBOUND_PROXY_URL"),
+		LiFiProxyURL:       viper.GetString("LIFI_PROXY_URL"),
+		SocketProxyURL:     viper.GetString("SOCKET_PROXY_URL"),
+		ZeroXProxyURL:      viper.GetString("ZEROX_PROXY_URL"),
+		OneInchProxyURL:    viper.GetString("ONEINCH_PROXY_URL"),
+		EgressAllowedHosts: viper.GetString("EGRESS_ALLOWED_HOSTS"),
 		
+		WorkerRPCAddr:   viper.GetString("WORKER_RPC_ADDR"),
+		WorkerRPCURL:    viper.GetString("WORKER_RPC_URL"),
+		WorkerRPCToken:  viper.GetString("WORKER_RPC_TOKEN"),
+
+		TelegramBotToken:     viper.GetString("TELEGRAM_BOT_TOKEN"),
+		WebhookAllowInsecure: viper.GetBool("WEBHOOK_ALLOW_INSECURE"),
+
+		EmailProvider:           viper.GetString("EMAIL_PROVIDER"),
+		EmailFrom:               viper.GetString("EMAIL_FROM"),
+		EmailSESRegion:          viper.GetString("EMAIL_SES_REGION"),
+		EmailSESAccessKeyID:     viper.GetString("EMAIL_SES_ACCESS_KEY_ID"),
+		EmailSESSecretAccessKey: viper.GetString("EMAIL_SES_SECRET_ACCESS_KEY"),
+		SendGridAPIKey:          viper.GetString("SENDGRID_API_KEY"),
+		SMTPHost:                viper.GetString("SMTP_HOST"),
+		SMTPPort:                viper.GetInt("SMTP_PORT"),
+		SMTPUsername:            viper.GetString("SMTP_USERNAME"),
+		SMTPPassword:            viper.GetString("SMTP_PASSWORD"),
+		EmailWebhookSecret:      viper.GetString("EMAIL_WEBHOOK_SECRET"),
+
 		RedisURL:        viper.GetString("REDIS_URL"),
+
+		FinalityThresholds: viper.GetString("FINALITY_THRESHOLDS"),
+		RPCBatchSize:       viper.GetInt("RPC_BATCH_SIZE"),
+		RPCBatchSizes:      viper.GetString("RPC_BATCH_SIZES"),
+		TestnetMode:        viper.GetBool("TESTNET_MODE"),
+		MockProviders:      viper.GetBool("MOCK_PROVIDERS"),
+
+		EncryptionKey:          viper.GetString("ENCRYPTION_KEY"),
+		EncryptionPreviousKeys: viper.GetString("ENCRYPTION_PREVIOUS_KEYS"),
+		AttestationSigningKey:  viper.GetString("ATTESTATION_SIGNING_KEY"),
+
+		SecretsProvider:        viper.GetString("SECRETS_PROVIDER"),
+		SecretsRefreshInterval: viper.GetInt("SECRETS_REFRESH_INTERVAL"),
+		VaultAddr:              viper.GetString("VAULT_ADDR"),
+		VaultToken:             viper.GetString("VAULT_TOKEN"),
+		VaultSecretPath:        viper.GetString("VAULT_SECRET_PATH"),
+		AWSRegion:              viper.GetString("AWS_REGION"),
+		AWSSecretID:            viper.GetString("AWS_SECRET_ID"),
+		AWSAccessKeyID:         viper.GetString("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey:     viper.GetString("AWS_SECRET_ACCESS_KEY"),
+		AWSSessionToken:        viper.GetString("AWS_SESSION_TOKEN"),
+
+		ProviderPolicyReloadInterval: viper.GetInt("PROVIDER_POLICY_RELOAD_INTERVAL"),
+		LogSettingsReloadInterval:    viper.GetInt("LOG_SETTINGS_RELOAD_INTERVAL"),
+		DBSlowQueryThreshold:         viper.GetInt("DB_SLOW_QUERY_THRESHOLD"),
 	}
 
 	// Validate required fields
@@ -145,6 +464,27 @@ func Load() (*Config, error) {
 	if cfg.JWTSecret == "" {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
+	if (cfg.WorkerRPCAddr != "" || cfg.WorkerRPCURL != "") && cfg.WorkerRPCToken == "" {
+		return nil, fmt.Errorf("WORKER_RPC_TOKEN is required when the worker RPC is enabled")
+	}
+	if cfg.AlertHistoryRetentionDays < 0 {
+		return nil, fmt.Errorf("ALERT_HISTORY_RETENTION_DAYS must not be negative")
+	}
+	if cfg.LogSettingsReloadInterval <= 0 {
+		return nil, fmt.Errorf("LOG_SETTINGS_RELOAD_INTERVAL must be positive")
+	}
+	if err := cfg.validateEgress(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.GetAlchemyWebhooks(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.GetRPCBatchSizes(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.GetAttestationSigner(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
@@ -161,6 +501,9 @@ func (c *Config) GetLiFiClientConfig() clients.ClientConfig {
 			RequestsPerSecond: c.ExternalAPIRateLimitRPS,
 			BurstSize:         c.ExternalAPIRateLimitBurst,
 		},
+		ProxyURL:     c.proxyURL(c.LiFiProxyURL),
+		AllowedHosts: c.GetEgressAllowedHosts(),
+		Transport:    c.quoteTransport(),
 	}
 }
 
@@ -176,6 +519,9 @@ func (c *Config) GetSocketClientConfig() clients.ClientConfig {
 			RequestsPerSecond: c.ExternalAPIRateLimitRPS,
 			BurstSize:         c.ExternalAPIRateLimitBurst,
 		},
+		ProxyURL:     c.proxyURL(c.SocketProxyURL),
+		AllowedHosts: c.GetEgressAllowedHosts(),
+		Transport:    c.quoteTransport(),
 	}
 }
 
@@ -191,6 +537,9 @@ func (c *Config) GetZeroXClientConfig() clients.ClientConfig {
 			RequestsPerSecond: c.ExternalAPIRateLimitRPS,
 			BurstSize:         c.ExternalAPIRateLimitBurst,
 		},
+		ProxyURL:     c.proxyURL(c.ZeroXProxyURL),
+		AllowedHosts: c.GetEgressAllowedHosts(),
+		Transport:    c.quoteTransport(),
 	}
 }
 
@@ -206,5 +555,200 @@ func (c *Config) GetOneInchClientConfig() clients.ClientConfig {
 			RequestsPerSecond: c.ExternalAPIRateLimitRPS,
 			BurstSize:         c.ExternalAPIRateLimitBurst,
 		},
+		ProxyURL:     c.proxyURL(c.OneInchProxyURL),
+		AllowedHosts: c.GetEgressAllowedHosts(),
+		Transport:    c.quoteTransport(),
 	}
+}
+
+// quoteTransport returns the connection tuning shared by the quote providers
+func (c *Config) quoteTransport() clients.TransportConfig {
+	return clients.TransportConfig{
+		ConnectTimeout:        time.Duration(c.QuoteConnectTimeout) * time.Millisecond,
+		TLSHandshakeTimeout:   time.Duration(c.QuoteTLSHandshakeTimeout) * time.Millisecond,
+		ResponseHeaderTimeout: time.Duration(c.QuoteResponseHeaderTimeout) * time.Millisecond,
+		MaxIdleConns:          c.QuoteMaxIdleConns,
+		IdleConnTimeout:       clients.DefaultQuoteTransport.IdleConnTimeout,
+	}
+}
+
+// GetWebhookPolicy returns which URLs alert webhooks may be posted to
+func (c *Config) GetWebhookPolicy() netguard.Policy {
+	return netguard.Policy{AllowInsecure: c.WebhookAllowInsecure}
+}
+
+// GetBillingConfig returns the pro tier's Stripe subscription settings
+func (c *Config) GetBillingConfig() billing.Config {
+	return billing.Config{
+		PriceID:       c.StripeProPriceID,
+		SuccessURL:    c.BillingSuccessURL,
+		CancelURL:     c.BillingCancelURL,
+		WebhookSecret: c.StripeWebhookSecret,
+		GracePeriod:   time.Duration(c.BillingGracePeriodHours) * time.Hour,
+	}
+}
+
+// GetAnalyticsConfig returns where usage analytics events are sent
+func (c *Config) GetAnalyticsConfig() analytics.Config {
+	return analytics.Config{
+		Sink:            c.AnalyticsSink,
+		Salt:            c.AnalyticsSalt,
+		SegmentWriteKey: c.SegmentWriteKey,
+		ClickHouseURL:   c.ClickHouseURL,
+		ClickHouseTable: c.ClickHouseTable,
+	}
+}
+
+// GetAlertMaxDataAge returns how old prices may be before alerts reading them are skipped
+func (c *Config) GetAlertMaxDataAge() time.Duration {
+	return time.Duration(c.AlertMaxDataAgeMinutes) * time.Minute
+}
+
+// GetAlertTargetGoneAfter returns how long a pool or token may go without updates
+// before alerts on it are expired
+func (c *Config) GetAlertTargetGoneAfter() time.Duration {
+	return time.Duration(c.AlertTargetGoneHours) * time.Hour
+}
+
+// GetAlertHistoryRetention returns how long alert triggers are kept, 0 for forever
+func (c *Config) GetAlertHistoryRetention() time.Duration {
+	return time.Duration(c.AlertHistoryRetentionDays) * 24 * time.Hour
+}
+
+// GetEncryptor returns the encryptor for secrets at rest, or nil if ENCRYPTION_KEY is unset
+func (c *Config) GetEncryptor() (*crypto.Encryptor, error) {
+	if c.EncryptionKey == "" {
+		return nil, nil
+	}
+
+	key, err := crypto.ParseKey(c.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENCRYPTION_KEY: %w", err)
+	}
+
+	var previous [][]byte
+	for _, encoded := range strings.Split(c.EncryptionPreviousKeys, ",") {
+		if encoded = strings.TrimSpace(encoded); encoded == "" {
+			continue
+		}
+		old, err := crypto.ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENCRYPTION_PREVIOUS_KEYS: %w", err)
+		}
+		previous = append(previous, old)
+	}
+
+	return crypto.NewKeyring(key, previous...)
+}
+
+// GetAttestationSigner returns the signer of portfolio attestations, or nil if
+// ATTESTATION_SIGNING_KEY is unset
+func (c *Config) GetAttestationSigner() (*attestation.Signer, error) {
+	if c.AttestationSigningKey == "" {
+		return nil, nil
+	}
+
+	seed, err := crypto.ParseKey(c.AttestationSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATTESTATION_SIGNING_KEY: %w", err)
+	}
+	return attestation.NewSigner(seed)
+}
+
+// GetAllowOrigins splits ALLOW_ORIGINS into the origins browsers may call from
+func (c *Config) GetAllowOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(c.AllowOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// GetAPIV1Sunset parses API_V1_SUNSET, returning nil if it's unset
+func (c *Config) GetAPIV1Sunset() (*time.Time, error) {
+	if strings.TrimSpace(c.APIV1Sunset) == "" {
+		return nil, nil
+	}
+
+	sunset, err := time.Parse("2006-01-02", strings.TrimSpace(c.APIV1Sunset))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_V1_SUNSET %q, expected YYYY-MM-DD", c.APIV1Sunset)
+	}
+	return &sunset, nil
+}
+
+// GetFinalityThresholds parses FINALITY_THRESHOLDS ("chainID:confirmations,...") into a map
+func (c *Config) GetFinalityThresholds() (map[int]int64, error) {
+	thresholds := make(map[int]int64)
+	if strings.TrimSpace(c.FinalityThresholds) == "" {
+		return thresholds, nil
+	}
+
+	for _, entry := range strings.Split(c.FinalityThresholds, ",") {
+		chain, confirmations, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid FINALITY_THRESHOLDS entry %q, expected chainID:confirmations", entry)
+		}
+		chainID, err := strconv.Atoi(chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain ID in FINALITY_THRESHOLDS: %q", chain)
+		}
+		threshold, err := strconv.ParseInt(confirmations, 10, 64)
+		if err != nil || threshold < 1 {
+			return nil, fmt.Errorf("invalid confirmations for chain %d in FINALITY_THRESHOLDS: %q", chainID, confirmations)
+		}
+		thresholds[chainID] = threshold
+	}
+
+	return thresholds, nil
+}
+
+// GetRPCBatchSizes parses RPC_BATCH_SIZES ("chainID:size,...") into a map
+func (c *Config) GetRPCBatchSizes() (map[int]int, error) {
+	sizes := make(map[int]int)
+	if strings.TrimSpace(c.RPCBatchSizes) == "" {
+		return sizes, nil
+	}
+
+	for _, entry := range strings.Split(c.RPCBatchSizes, ",") {
+		chain, size, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid RPC_BATCH_SIZES entry %q, expected chainID:size", entry)
+		}
+		chainID, err := strconv.Atoi(chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain ID in RPC_BATCH_SIZES: %q", chain)
+		}
+		batchSize, err := strconv.Atoi(size)
+		if err != nil || batchSize < 1 || batchSize > blockchain.MaxRPCBatchSize {
+			return nil, fmt.Errorf("invalid batch size for chain %d in RPC_BATCH_SIZES: %q", chainID, size)
+		}
+		sizes[chainID] = batchSize
+	}
+
+	return sizes, nil
+}
+
+// GetAlchemyWebhooks parses ALCHEMY_WEBHOOKS ("chainID:webhookID:signingKey,...")
+func (c *Config) GetAlchemyWebhooks() ([]blockchain.AlchemyWebhook, error) {
+	var webhooks []blockchain.AlchemyWebhook
+	if strings.TrimSpace(c.AlchemyWebhooks) == "" {
+		return webhooks, nil
+	}
+
+	for _, entry := range strings.Split(c.AlchemyWebhooks, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid ALCHEMY_WEBHOOKS entry, expected chainID:webhookID:signingKey")
+		}
+		chainID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain ID in ALCHEMY_WEBHOOKS: %q", parts[0])
+		}
+		webhooks = append(webhooks, blockchain.AlchemyWebhook{ID: parts[1], ChainID: chainID, SigningKey: parts[2]})
+	}
+
+	return webhooks, nil
 }
\ No newline at end of file
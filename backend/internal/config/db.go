@@ -5,9 +5,25 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/defi-dashboard/backend/pkg/dbtrace"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// GetDBSlowQueryThreshold returns how long a query runs before it's logged as slow
+func (c *Config) GetDBSlowQueryThreshold() time.Duration {
+	return time.Duration(c.DBSlowQueryThreshold) * time.Millisecond
+}
+
+// NewTracedPool connects to the database with queries timed by tracer
+func (c *Config) NewTracedPool(ctx context.Context, tracer *dbtrace.Tracer) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(c.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = tracer
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
 // NewDatabasePool creates a new PostgreSQL connection pool
 func NewDatabasePool(databaseURL string) (*pgxpool.Pool, error) {
 	// Parse and validate the database URL
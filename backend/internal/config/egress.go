@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+)
+
+// proxyURL returns the proxy a provider client uses: its own if set, otherwise
+// OUTBOUND_PROXY_URL
+func (c *Config) proxyURL(clientProxy string) string {
+	if clientProxy != "" {
+		return clientProxy
+	}
+	return c.OutboundProxyURL
+}
+
+// GetEgressAllowedHosts returns the hosts provider clients may call, or nil when any
+// host is allowed
+func (c *Config) GetEgressAllowedHosts() []string {
+	var hosts []string
+	for _, host := range strings.Split(c.EgressAllowedHosts, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// validateEgress checks the proxy URLs and that every provider the clients are
+// configured to call is on the egress allowlist, so a mismatch fails at startup rather
+// than on the first request
+func (c *Config) validateEgress() error {
+	proxies := map[string]string{
+		"OUTBOUND_PROXY_URL": c.OutboundProxyURL,
+		"LIFI_PROXY_URL":     c.LiFiProxyURL,
+		"SOCKET_PROXY_URL":   c.SocketProxyURL,
+		"ZEROX_PROXY_URL":    c.ZeroXProxyURL,
+		"ONEINCH_PROXY_URL":  c.OneInchProxyURL,
+	}
+	for name, proxy := range proxies {
+		if proxy == "" {
+			continue
+		}
+		if _, err := clients.ParseProxyURL(proxy); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	allowed := c.GetEgressAllowedHosts()
+	if len(allowed) == 0 {
+		return nil
+	}
+	baseURLs := map[string]string{
+		"LIFI_BASE_URL":    c.LiFiBaseURL,
+		"SOCKET_BASE_URL":  c.SocketBaseURL,
+		"ZEROX_BASE_URL":   c.ZeroXBaseURL,
+		"ONEINCH_BASE_URL": c.OneInchBaseURL,
+	}
+	for name, baseURL := range baseURLs {
+		if baseURL == "" {
+			continue
+		}
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid %s: %q", name, baseURL)
+		}
+		if !clients.HostAllowed(u.Host, allowed) {
+			return fmt.Errorf("%s host %s is not in EGRESS_ALLOWED_HOSTS", name, u.Hostname())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/defi-dashboard/backend/pkg/email"
+)
+
+// NewEmailSender builds the sender for EMAIL_PROVIDER. SES falls back to the AWS
+// credentials and region used for secrets.
+func (c *Config) NewEmailSender() (email.Sender, error) {
+	cfg := email.Config{
+		Provider:           c.EmailProvider,
+		From:               c.EmailFrom,
+		SESRegion:          c.EmailSESRegion,
+		SESAccessKeyID:     c.EmailSESAccessKeyID,
+		SESSecretAccessKey: c.EmailSESSecretAccessKey,
+		SendGridAPIKey:     c.SendGridAPIKey,
+		SMTPHost:           c.SMTPHost,
+		SMTPPort:           c.SMTPPort,
+		SMTPUsername:       c.SMTPUsername,
+		SMTPPassword:       c.SMTPPassword,
+	}
+	if cfg.SESRegion == "" {
+		cfg.SESRegion = c.AWSRegion
+	}
+	if cfg.SESAccessKeyID == "" && cfg.SESSecretAccessKey == "" {
+		cfg.SESAccessKeyID = c.AWSAccessKeyID
+		cfg.SESSecretAccessKey = c.AWSSecretAccessKey
+		cfg.SESSessionToken = c.AWSSessionToken
+	}
+
+	sender, err := email.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s email sender: %w", c.EmailProvider, err)
+	}
+	return sender, nil
+}
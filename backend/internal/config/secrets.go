@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/secrets"
+)
+
+// Secret names, shared with the environment variables they replace
+const (
+	SecretAlchemyAPIKey   = "ALCHEMY_API_KEY"
+	SecretCoinGeckoAPIKey = "COINGECKO_API_KEY"
+	SecretZeroXAPIKey     = "ZEROX_API_KEY"
+	SecretLiFiAPIKey      = "LIFI_API_KEY"
+	SecretSocketAPIKey    = "SOCKET_API_KEY"
+	SecretOneInchAPIKey   = "ONEINCH_API_KEY"
+	SecretInfuraAPIKey    = "INFURA_API_KEY"
+	SecretEtherscanAPIKey = "ETHERSCAN_API_KEY"
+)
+
+// NewSecretsManager builds the manager for SECRETS_PROVIDER, loads the initial
+// values and copies them over the env-derived API keys. It returns nil when
+// secrets come from the environment only.
+func (c *Config) NewSecretsManager(ctx context.Context) (*secrets.Manager, error) {
+	var provider secrets.Provider
+	var err error
+
+	switch c.SecretsProvider {
+	case "", "env":
+		return nil, nil
+	case "vault":
+		provider, err = secrets.NewVaultProvider(c.VaultAddr, c.VaultToken, c.VaultSecretPath)
+	case "aws":
+		provider, err = secrets.NewAWSSecretsManagerProvider(c.AWSRegion, c.AWSSecretID, c.AWSAccessKeyID, c.AWSSecretAccessKey, c.AWSSessionToken)
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q", c.SecretsProvider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s secrets provider: %w", c.SecretsProvider, err)
+	}
+
+	manager := secrets.NewManager(provider)
+	if err := manager.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load secrets from %s: %w", provider.Name(), err)
+	}
+
+	fields := map[string]*string{
+		SecretAlchemyAPIKey:   &c.AlchemyAPIKey,
+		SecretCoinGeckoAPIKey: &c.CoinGeckoAPIKey,
+		SecretZeroXAPIKey:     &c.ZeroXAPIKey,
+		SecretLiFiAPIKey:      &c.LiFiAPIKey,
+		SecretSocketAPIKey:    &c.SocketAPIKey,
+		SecretOneInchAPIKey:   &c.OneInchAPIKey,
+		SecretInfuraAPIKey:    &c.InfuraAPIKey,
+		SecretEtherscanAPIKey: &c.EtherscanAPIKey,
+	}
+	for name, field := range fields {
+		if v := manager.Get(name); v != "" {
+			*field = v
+		}
+	}
+
+	return manager, nil
+}
+
+// GetSecretsRefreshInterval returns how often secrets are re-read for rotation
+func (c *Config) GetSecretsRefreshInterval() time.Duration {
+	return time.Duration(c.SecretsRefreshInterval) * time.Second
+}
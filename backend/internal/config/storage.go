@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/storage"
+)
+
+// NewStorage builds the object store for STORAGE_BACKEND. Local files are served by the
+// API under /api/v1/files.
+func (c *Config) NewStorage() (storage.Store, error) {
+	signingKey := c.StorageSigningKey
+	if signingKey == "" {
+		signingKey = c.JWTSecret
+	}
+
+	store, err := storage.New(storage.Config{
+		Backend:         c.StorageBackend,
+		LocalDir:        c.StorageLocalDir,
+		LocalBaseURL:    strings.TrimRight(c.PublicURL, "/") + "/api/v1/files",
+		SigningKey:      []byte(signingKey),
+		Bucket:          c.StorageBucket,
+		Endpoint:        c.StorageEndpoint,
+		Region:          c.StorageRegion,
+		AccessKeyID:     c.StorageAccessKeyID,
+		SecretAccessKey: c.StorageSecretAccessKey,
+		PathStyle:       c.StoragePathStyle,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s storage: %w", c.StorageBackend, err)
+	}
+	return store, nil
+}
+
+// GetUploadRetention returns how long uploads of each kind are kept. Kinds configured
+// with 0 days are left out and kept until deleted.
+func (c *Config) GetUploadRetention() map[string]time.Duration {
+	days := map[string]int{
+		models.UploadKindExport: c.StorageRetentionExportDays,
+		models.UploadKindReport: c.StorageRetentionReportDays,
+		models.UploadKindImport: c.StorageRetentionImportDays,
+	}
+
+	retention := make(map[string]time.Duration)
+	for kind, d := range days {
+		if d > 0 {
+			retention[kind] = time.Duration(d) * 24 * time.Hour
+		}
+	}
+	return retention
+}
+
+// GetSignedURLExpiry returns the default validity of download links
+func (c *Config) GetSignedURLExpiry() time.Duration {
+	return time.Duration(c.StorageSignedURLTTL) * time.Second
+}
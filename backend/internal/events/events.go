@@ -0,0 +1,79 @@
+// Package events carries realtime notifications from the worker to connected
+// API clients. Events are published with Postgres NOTIFY so they reach every
+// API replica, and fanned out to each user's websocket subscribers by a Hub.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the Postgres NOTIFY channel realtime events are published on
+const Channel = "realtime_events"
+
+// maxPayloadSize stays under Postgres' 8000 byte NOTIFY payload limit
+const maxPayloadSize = 7900
+
+// Event types
+const (
+	TypeChainReorg           = "chain.reorg"
+	TypeTransactionConfirmed = "transaction.confirmed"
+	TypeTransactionFailed    = "transaction.failed"
+	TypeTransactionDropped   = "transaction.dropped"
+	TypeLiquidationRisk      = "position.liquidation_risk"
+	TypeTaskProgress         = "task.progress"
+	TypeAlertTriggered       = "alert.triggered"
+	TypeBalanceChanged       = "balance.changed"
+	TypeOnboardingStep       = "onboarding.step_completed"
+)
+
+// Event is a realtime notification addressed to a single user
+type Event struct {
+	Type      string          `json:"type"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// NewEvent builds an event with data marshalled to JSON
+func NewEvent(eventType string, userID uuid.UUID, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	return Event{Type: eventType, UserID: userID, Data: raw, CreatedAt: time.Now().UTC()}, nil
+}
+
+// Publisher sends events to subscribed clients
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PGPublisher publishes events with Postgres NOTIFY
+type PGPublisher struct {
+	db *pgxpool.Pool
+}
+
+func NewPGPublisher(db *pgxpool.Pool) *PGPublisher {
+	return &PGPublisher{db: db}
+}
+
+func (p *PGPublisher) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if len(payload) > maxPayloadSize {
+		return fmt.Errorf("event %s payload is %d bytes, limit is %d", event.Type, len(payload), maxPayloadSize)
+	}
+
+	if _, err := p.db.Exec(ctx, `SELECT pg_notify($1, $2)`, Channel, string(payload)); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
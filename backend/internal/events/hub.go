@@ -0,0 +1,107 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before events are dropped
+const subscriberBuffer = 32
+
+// Hub fans events out to per-user subscribers
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uuid.UUID]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving the user's events and a function to unsubscribe
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Broadcast delivers an event to the user's subscribers without blocking;
+// subscribers whose buffer is full miss the event
+func (h *Hub) Broadcast(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			logger.Warn("Dropping realtime event for slow subscriber", "type", event.Type, "userID", event.UserID)
+		}
+	}
+}
+
+// Listen receives events published with NOTIFY and broadcasts them until ctx is
+// cancelled, reconnecting if the listening connection is lost
+func (h *Hub) Listen(ctx context.Context, db *pgxpool.Pool) {
+	for ctx.Err() == nil {
+		if err := h.listen(ctx, db); err != nil && ctx.Err() == nil {
+			logger.Error("Realtime event listener failed, reconnecting", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context, db *pgxpool.Pool) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	// Don't hand a LISTENing connection back to the pool
+	defer conn.Exec(context.Background(), "UNLISTEN "+Channel)
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			logger.Warn("Ignoring malformed realtime event", "error", err)
+			continue
+		}
+		h.Broadcast(event)
+	}
+}
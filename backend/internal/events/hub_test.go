@@ -0,0 +1,49 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_BroadcastToUser(t *testing.T) {
+	hub := NewHub()
+	alice, bob := uuid.New(), uuid.New()
+
+	aliceEvents, unsubscribe := hub.Subscribe(alice)
+	bobEvents, unsubscribeBob := hub.Subscribe(bob)
+	defer unsubscribeBob()
+
+	event, err := NewEvent(TypeChainReorg, alice, map[string]int{"block_number": 100})
+	require.NoError(t, err)
+	hub.Broadcast(event)
+
+	got := <-aliceEvents
+	assert.Equal(t, TypeChainReorg, got.Type)
+	assert.JSONEq(t, `{"block_number":100}`, string(got.Data))
+	assert.Empty(t, bobEvents)
+
+	// Unsubscribing closes the channel and is idempotent
+	unsubscribe()
+	unsubscribe()
+	_, open := <-aliceEvents
+	assert.False(t, open)
+	hub.Broadcast(event)
+}
+
+func TestHub_SlowSubscriberDoesNotBlock(t *testing.T) {
+	hub := NewHub()
+	userID := uuid.New()
+	events, unsubscribe := hub.Subscribe(userID)
+	defer unsubscribe()
+
+	event, err := NewEvent(TypeChainReorg, userID, nil)
+	require.NoError(t, err)
+	for i := 0; i < subscriberBuffer+10; i++ {
+		hub.Broadcast(event)
+	}
+
+	assert.Len(t, events, subscriberBuffer)
+}
@@ -0,0 +1,319 @@
+// Package fixtures is a deterministic demo data set: users with wallets, balances,
+// transactions, yield positions and alerts. cmd/seed loads it for local development and
+// integration tests load it to have known rows to assert against.
+package fixtures
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/google/uuid"
+)
+
+// namespace derives fixture IDs, so the same fixture always has the same ID
+var namespace = uuid.MustParse("6f1d7a0e-4c5b-4d43-9a53-0b8f6c1e2d10")
+
+// ID returns the ID of a named fixture, e.g. ID("user:alice")
+func ID(name string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(name))
+}
+
+// Hash returns a 32-byte transaction hash derived from a fixture name
+func Hash(name string) string {
+	sum := sha256.Sum256([]byte("fixtures:" + name))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// NativeToken is the address fixtures use for a chain's gas token
+const NativeToken = "0x0000000000000000000000000000000000000000"
+
+// Demo users' addresses
+const (
+	AliceAddress = "0xa11ce00000000000000000000000000000000001"
+	BobAddress   = "0xb0b0000000000000000000000000000000000002"
+	CarolAddress = "0xca40100000000000000000000000000000000003"
+)
+
+type User struct {
+	ID      uuid.UUID
+	Address string
+	Email   *string
+	IsAdmin bool
+}
+
+type Wallet struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Address   string
+	ChainID   int
+	Label     string
+	IsPrimary bool
+	IsTestnet bool
+}
+
+type Token struct {
+	ID       uuid.UUID
+	Address  string
+	ChainID  int
+	Symbol   string
+	Name     string
+	Decimals int
+	PriceUSD float64
+}
+
+// Balance is a wallet's holding of a token in whole units
+type Balance struct {
+	WalletID uuid.UUID
+	TokenID  uuid.UUID
+	Amount   float64
+}
+
+type Transaction struct {
+	ID        uuid.UUID
+	Hash      string
+	WalletID  uuid.UUID
+	ChainID   int
+	From      string
+	To        string
+	Value     string // wei
+	GasUsed   int64
+	GasPrice  string // wei
+	GasFeeUSD float64
+	Block     int64
+	Timestamp time.Time
+	Type      string
+	Metadata  map[string]interface{}
+}
+
+type YieldPool struct {
+	ID           uuid.UUID
+	PoolID       string
+	ProtocolSlug string
+	Protocol     string
+	Name         string
+	Chain        string
+	ChainID      int
+	Symbol       string
+	TVLUSD       float64
+	APYBase      float64
+	APYReward    float64
+	RiskLevel    string
+	StableCoin   bool
+}
+
+type YieldPosition struct {
+	ID          uuid.UUID
+	WalletID    uuid.UUID
+	PoolID      uuid.UUID
+	EntryUSD    float64
+	CurrentUSD  float64
+	RewardsUSD  float64
+	EntryTime   time.Time
+	EntryTxHash string
+}
+
+type Alert struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Type         string
+	Target       models.AlertTarget
+	Conditions   models.AlertConditions
+	Notification models.AlertNotification
+}
+
+// Set is a complete fixture data set. Rows refer to each other by fixture ID.
+type Set struct {
+	Users          []User
+	Wallets        []Wallet
+	Tokens         []Token
+	Balances       []Balance
+	Transactions   []Transaction
+	YieldPools     []YieldPool
+	YieldPositions []YieldPosition
+	Alerts         []Alert
+}
+
+// Default returns the demo data set with transactions dated back from now. Pass a fixed
+// time for reproducible timestamps.
+func Default(now time.Time) *Set {
+	now = now.UTC().Truncate(time.Hour)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	str := func(s string) *string { return &s }
+	number := func(f float64) *float64 { return &f }
+
+	alice, bob, carol := ID("user:alice"), ID("user:bob"), ID("user:carol")
+	set := &Set{
+		Users: []User{
+			{ID: alice, Address: AliceAddress, Email: str("alice@example.com"), IsAdmin: true},
+			{ID: bob, Address: BobAddress, Email: str("bob@example.com")},
+			{ID: carol, Address: CarolAddress},
+		},
+		Wallets: []Wallet{
+			{ID: ID("wallet:alice:1"), UserID: alice, Address: AliceAddress, ChainID: 1, Label: "Main Wallet", IsPrimary: true},
+			{ID: ID("wallet:alice:137"), UserID: alice, Address: AliceAddress, ChainID: 137, Label: "Polygon"},
+			{ID: ID("wallet:alice:42161"), UserID: alice, Address: AliceAddress, ChainID: 42161, Label: "Arbitrum"},
+			{ID: ID("wallet:alice:80002"), UserID: alice, Address: AliceAddress, ChainID: 80002, Label: "Amoy Testnet", IsTestnet: true},
+			{ID: ID("wallet:bob:1"), UserID: bob, Address: BobAddress, ChainID: 1, Label: "Main Wallet", IsPrimary: true},
+			{ID: ID("wallet:bob:10"), UserID: bob, Address: BobAddress, ChainID: 10, Label: "Optimism"},
+			{ID: ID("wallet:carol:1"), UserID: carol, Address: CarolAddress, ChainID: 1, Label: "Main Wallet", IsPrimary: true},
+		},
+		Tokens: []Token{
+			{ID: ID("token:1:ETH"), Address: NativeToken, ChainID: 1, Symbol: "ETH", Name: "Ether", Decimals: 18, PriceUSD: 3150.42},
+			{ID: ID("token:1:WETH"), Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", ChainID: 1, Symbol: "WETH", Name: "Wrapped Ether", Decimals: 18, PriceUSD: 3150.42},
+			{ID: ID("token:1:USDC"), Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", ChainID: 1, Symbol: "USDC", Name: "USD Coin", Decimals: 6, PriceUSD: 1.0},
+			{ID: ID("token:1:DAI"), Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", ChainID: 1, Symbol: "DAI", Name: "Dai Stablecoin", Decimals: 18, PriceUSD: 0.9998},
+			{ID: ID("token:1:WBTC"), Address: "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", ChainID: 1, Symbol: "WBTC", Name: "Wrapped BTC", Decimals: 8, PriceUSD: 64210.5},
+			{ID: ID("token:1:stETH"), Address: "0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84", ChainID: 1, Symbol: "stETH", Name: "Lido Staked Ether", Decimals: 18, PriceUSD: 3148.9},
+			{ID: ID("token:137:MATIC"), Address: NativeToken, ChainID: 137, Symbol: "MATIC", Name: "Polygon", Decimals: 18, PriceUSD: 0.72},
+			{ID: ID("token:137:USDC"), Address: "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", ChainID: 137, Symbol: "USDC", Name: "USD Coin", Decimals: 6, PriceUSD: 1.0},
+			{ID: ID("token:42161:ETH"), Address: NativeToken, ChainID: 42161, Symbol: "ETH", Name: "Ether", Decimals: 18, PriceUSD: 3150.42},
+			{ID: ID("token:42161:ARB"), Address: "0x912CE59144191C1204E64559FE8253a0e49E6548", ChainID: 42161, Symbol: "ARB", Name: "Arbitrum", Decimals: 18, PriceUSD: 1.12},
+			{ID: ID("token:80002:MATIC"), Address: NativeToken, ChainID: 80002, Symbol: "MATIC", Name: "Polygon", Decimals: 18, PriceUSD: 0.72},
+			{ID: ID("token:10:ETH"), Address: NativeToken, ChainID: 10, Symbol: "ETH", Name: "Ether", Decimals: 18, PriceUSD: 3150.42},
+			{ID: ID("token:10:OP"), Address: "0x4200000000000000000000000000000000000042", ChainID: 10, Symbol: "OP", Name: "Optimism", Decimals: 18, PriceUSD: 2.34},
+		},
+		Balances: []Balance{
+			{WalletID: ID("wallet:alice:1"), TokenID: ID("token:1:ETH"), Amount: 4.2},
+			{WalletID: ID("wallet:alice:1"), TokenID: ID("token:1:USDC"), Amount: 12500},
+			{WalletID: ID("wallet:alice:1"), TokenID: ID("token:1:WBTC"), Amount: 0.15},
+			{WalletID: ID("wallet:alice:1"), TokenID: ID("token:1:stETH"), Amount: 2},
+			{WalletID: ID("wallet:alice:137"), TokenID: ID("token:137:MATIC"), Amount: 850},
+			{WalletID: ID("wallet:alice:137"), TokenID: ID("token:137:USDC"), Amount: 1800},
+			{WalletID: ID("wallet:alice:42161"), TokenID: ID("token:42161:ETH"), Amount: 0.8},
+			{WalletID: ID("wallet:alice:42161"), TokenID: ID("token:42161:ARB"), Amount: 1500},
+			{WalletID: ID("wallet:alice:80002"), TokenID: ID("token:80002:MATIC"), Amount: 25},
+			{WalletID: ID("wallet:bob:1"), TokenID: ID("token:1:ETH"), Amount: 1.35},
+			{WalletID: ID("wallet:bob:1"), TokenID: ID("token:1:DAI"), Amount: 4200},
+			{WalletID: ID("wallet:bob:10"), TokenID: ID("token:10:ETH"), Amount: 0.4},
+			{WalletID: ID("wallet:bob:10"), TokenID: ID("token:10:OP"), Amount: 900},
+			{WalletID: ID("wallet:carol:1"), TokenID: ID("token:1:ETH"), Amount: 0.05},
+			{WalletID: ID("wallet:carol:1"), TokenID: ID("token:1:USDC"), Amount: 250},
+		},
+		YieldPools: []YieldPool{
+			{ID: ID("pool:aave-v3-usdc"), PoolID: "fixture-aave-v3-usdc-ethereum", ProtocolSlug: "aave-v3", Protocol: "Aave V3", Name: "USDC Supply", Chain: "ethereum", ChainID: 1, Symbol: "USDC", TVLUSD: 1_250_000_000, APYBase: 4.12, APYReward: 0.35, RiskLevel: "low", StableCoin: true},
+			{ID: ID("pool:uniswap-v3-weth-usdc"), PoolID: "fixture-uniswap-v3-weth-usdc-ethereum", ProtocolSlug: "uniswap-v3", Protocol: "Uniswap V3", Name: "WETH-USDC 0.05%", Chain: "ethereum", ChainID: 1, Symbol: "WETH-USDC", TVLUSD: 310_000_000, APYBase: 18.6, RiskLevel: "medium"},
+			{ID: ID("pool:curve-3pool"), PoolID: "fixture-curve-3pool-ethereum", ProtocolSlug: "curve", Protocol: "Curve Finance", Name: "3pool", Chain: "ethereum", ChainID: 1, Symbol: "DAI-USDC-USDT", TVLUSD: 180_000_000, APYBase: 1.9, APYReward: 2.4, RiskLevel: "low", StableCoin: true},
+			{ID: ID("pool:lido-steth"), PoolID: "fixture-lido-steth-ethereum", ProtocolSlug: "lido", Protocol: "Lido", Name: "stETH", Chain: "ethereum", ChainID: 1, Symbol: "STETH", TVLUSD: 24_000_000_000, APYBase: 3.1, RiskLevel: "low"},
+		},
+		YieldPositions: []YieldPosition{
+			{ID: ID("position:alice:aave-v3-usdc"), WalletID: ID("wallet:alice:1"), PoolID: ID("pool:aave-v3-usdc"), EntryUSD: 10000, CurrentUSD: 10184.2, RewardsUSD: 21.7, EntryTime: daysAgo(60), EntryTxHash: Hash("alice:aave-supply")},
+			{ID: ID("position:alice:lido-steth"), WalletID: ID("wallet:alice:1"), PoolID: ID("pool:lido-steth"), EntryUSD: 5600, CurrentUSD: 6297.8, EntryTime: daysAgo(120), EntryTxHash: Hash("alice:lido-stake")},
+			{ID: ID("position:bob:uniswap-v3-weth-usdc"), WalletID: ID("wallet:bob:1"), PoolID: ID("pool:uniswap-v3-weth-usdc"), EntryUSD: 4000, CurrentUSD: 3872.4, RewardsUSD: 96.3, EntryTime: daysAgo(21), EntryTxHash: Hash("bob:uniswap-mint")},
+		},
+		Alerts: []Alert{
+			{
+				ID: ID("alert:alice:eth-above"), UserID: alice, Type: models.AlertTypePriceAbove,
+				Target:       models.AlertTarget{Type: "token", Identifier: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", ChainID: 1},
+				Conditions:   models.AlertConditions{Price: number(4000)},
+				Notification: models.AlertNotification{Email: true},
+			},
+			{
+				ID: ID("alert:alice:large-transfer"), UserID: alice, Type: models.AlertTypeLargeTransfer,
+				Target:       models.AlertTarget{Type: "address", Identifier: AliceAddress, ChainID: 1},
+				Conditions:   models.AlertConditions{Threshold: str("1000000000000000000")},
+				Notification: models.AlertNotification{Email: true},
+			},
+			{
+				ID: ID("alert:bob:aave-apr"), UserID: bob, Type: models.AlertTypeAPRChange,
+				Target:       models.AlertTarget{Type: "pool", Identifier: "fixture-aave-v3-usdc-ethereum", ChainID: 1},
+				Conditions:   models.AlertConditions{MinAPR: number(3.5)},
+				Notification: models.AlertNotification{Webhook: "https://example.com/hooks/alerts"},
+			},
+		},
+	}
+
+	// Typical on-chain activity, newest last
+	type activity struct {
+		name, wallet, counterparty, txType, function string
+		chainID                                      int
+		valueWei                                     string
+		gasUsed                                      int64
+		gwei                                         int64
+		days                                         int
+	}
+	activities := []activity{
+		{"alice:receive-eth", "wallet:alice:1", BobAddress, "receive", "", 1, "2000000000000000000", 21000, 24, 90},
+		{"alice:lido-stake", "wallet:alice:1", "0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84", "stake", "submit", 1, "2000000000000000000", 78000, 22, 120},
+		{"alice:usdc-approve", "wallet:alice:1", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "approve", "approve", 1, "0", 46000, 18, 61},
+		{"alice:aave-supply", "wallet:alice:1", "0x87870Bca3F3fD6335C3F4ce8392D69350B4fA4E2", "stake", "supply", 1, "0", 215000, 18, 60},
+		{"alice:swap-usdc-wbtc", "wallet:alice:1", "0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD", "swap", "execute", 1, "0", 182000, 15, 14},
+		{"alice:bridge-arbitrum", "wallet:alice:1", "0x72Ce9c846789fdB6fC1f34aC4AD25Dd9ef7031ef", "bridge", "outboundTransfer", 1, "800000000000000000", 120000, 14, 9},
+		{"alice:polygon-receive", "wallet:alice:137", CarolAddress, "receive", "", 137, "0", 65000, 60, 5},
+		{"alice:send-bob", "wallet:alice:1", BobAddress, "send", "", 1, "250000000000000000", 21000, 12, 2},
+		{"bob:uniswap-mint", "wallet:bob:1", "0xC36442b4a4522E871399CD717aBDD847Ab11FE88", "stake", "mint", 1, "0", 410000, 20, 21},
+		{"bob:op-swap", "wallet:bob:10", "0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45", "swap", "exactInputSingle", 10, "100000000000000000", 140000, 0, 7},
+		{"carol:receive-usdc", "wallet:carol:1", AliceAddress, "receive", "transfer", 1, "0", 52000, 16, 3},
+	}
+
+	wallets := make(map[uuid.UUID]Wallet)
+	for _, wallet := range set.Wallets {
+		wallets[wallet.ID] = wallet
+	}
+	for i, a := range activities {
+		wallet := wallets[ID(a.wallet)]
+		from, to := wallet.Address, a.counterparty
+		if a.txType == "receive" {
+			from, to = a.counterparty, wallet.Address
+		}
+		gasPrice := new(big.Int).Mul(big.NewInt(a.gwei), big.NewInt(1e9))
+		if a.gwei == 0 {
+			gasPrice = big.NewInt(1_000_000) // L2 gas is fractions of a gwei
+		}
+		feeETH, _ := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Mul(gasPrice, big.NewInt(a.gasUsed))), big.NewFloat(1e18)).Float64()
+
+		var metadata map[string]interface{}
+		if a.function != "" {
+			metadata = map[string]interface{}{"functionName": a.function}
+		}
+		set.Transactions = append(set.Transactions, Transaction{
+			ID:        ID("tx:" + a.name),
+			Hash:      Hash(a.name),
+			WalletID:  wallet.ID,
+			ChainID:   a.chainID,
+			From:      from,
+			To:        to,
+			Value:     a.valueWei,
+			GasUsed:   a.gasUsed,
+			GasPrice:  gasPrice.String(),
+			GasFeeUSD: math.Round(feeETH*nativePrice(a.chainID)*100) / 100,
+			Block:     blockAt(a.chainID, a.days) + int64(i),
+			Timestamp: daysAgo(a.days),
+			Type:      a.txType,
+			Metadata:  metadata,
+		})
+	}
+
+	return set
+}
+
+// nativePrice is the fixture price of a chain's gas token
+func nativePrice(chainID int) float64 {
+	if chainID == 137 || chainID == 80002 {
+		return 0.72
+	}
+	return 3150.42
+}
+
+// blockAt returns a plausible block number for a chain some days ago
+func blockAt(chainID int, days int) int64 {
+	head, perDay := int64(21_500_000), int64(7_200)
+	switch chainID {
+	case 137:
+		head, perDay = 65_000_000, 40_000
+	case 42161:
+		head, perDay = 280_000_000, 345_000
+	case 10:
+		head, perDay = 128_000_000, 43_200
+	}
+	return head - int64(days)*perDay
+}
+
+// RawAmount converts a whole-unit amount to the token's base units
+func RawAmount(amount float64, decimals int) string {
+	return decimal.NewFromFloat(amount).Mul(decimal.New(1, -int32(decimals))).Round(0).String()
+}
@@ -0,0 +1,79 @@
+package fixtures
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultIsDeterministic(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, Default(now), Default(now))
+	assert.Equal(t, ID("user:alice"), Default(now).Users[0].ID)
+
+	tx := Default(now).Transactions[0]
+	assert.Len(t, tx.Hash, 66)
+	assert.Equal(t, Hash("alice:receive-eth"), tx.Hash)
+	assert.Equal(t, now.AddDate(0, 0, -90), tx.Timestamp)
+}
+
+func TestDefaultReferencesResolve(t *testing.T) {
+	set := Default(time.Now())
+
+	ids := make(map[uuid.UUID]bool)
+	add := func(id uuid.UUID) {
+		require.False(t, ids[id], "duplicate fixture ID %s", id)
+		ids[id] = true
+	}
+	users := make(map[uuid.UUID]bool)
+	for _, user := range set.Users {
+		add(user.ID)
+		users[user.ID] = true
+	}
+	wallets := make(map[uuid.UUID]bool)
+	for _, wallet := range set.Wallets {
+		add(wallet.ID)
+		wallets[wallet.ID] = true
+		assert.True(t, users[wallet.UserID], "wallet %s has no user", wallet.Label)
+	}
+	tokens := make(map[uuid.UUID]bool)
+	for _, token := range set.Tokens {
+		add(token.ID)
+		tokens[token.ID] = true
+	}
+	for _, balance := range set.Balances {
+		assert.True(t, wallets[balance.WalletID])
+		assert.True(t, tokens[balance.TokenID])
+	}
+	hashes := make(map[string]bool)
+	for _, tx := range set.Transactions {
+		add(tx.ID)
+		assert.True(t, wallets[tx.WalletID])
+		assert.False(t, hashes[tx.Hash], "duplicate hash %s", tx.Hash)
+		hashes[tx.Hash] = true
+	}
+	pools := make(map[uuid.UUID]bool)
+	for _, pool := range set.YieldPools {
+		add(pool.ID)
+		pools[pool.ID] = true
+	}
+	for _, position := range set.YieldPositions {
+		add(position.ID)
+		assert.True(t, wallets[position.WalletID])
+		assert.True(t, pools[position.PoolID])
+		assert.True(t, hashes[position.EntryTxHash], "position %s has no entry transaction", position.ID)
+	}
+	for _, alert := range set.Alerts {
+		add(alert.ID)
+		assert.True(t, users[alert.UserID])
+	}
+}
+
+func TestRawAmount(t *testing.T) {
+	assert.Equal(t, "4200000000000000000", RawAmount(4.2, 18))
+	assert.Equal(t, "12500000000", RawAmount(12500, 6))
+	assert.Equal(t, "15000000", RawAmount(0.15, 8))
+}
@@ -0,0 +1,226 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Load writes set to the database in one transaction. Loading is idempotent: fixture rows
+// are upserted, so running it again refreshes them. Tokens, transactions and pools that
+// already exist under their natural keys are reused and references remapped to them.
+func Load(ctx context.Context, db *pgxpool.Pool, set *Set) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, user := range set.Users {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO users (id, address, nonce, email, is_admin)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id) DO UPDATE SET
+				address = EXCLUDED.address,
+				email = EXCLUDED.email,
+				is_admin = EXCLUDED.is_admin`,
+			user.ID, user.Address, uuid.NewString(), user.Email, user.IsAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to load user %s: %w", user.Address, err)
+		}
+	}
+
+	wallets := make(map[uuid.UUID]Wallet)
+	for _, wallet := range set.Wallets {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO wallets (id, user_id, address, chain_id, chain_reference, label, is_primary, is_testnet)
+			VALUES ($1, $2, $3, $4, $4::text, $5, $6, $7)
+			ON CONFLICT (id) DO UPDATE SET
+				label = EXCLUDED.label,
+				is_primary = EXCLUDED.is_primary,
+				is_testnet = EXCLUDED.is_testnet`,
+			wallet.ID, wallet.UserID, wallet.Address, wallet.ChainID, wallet.Label, wallet.IsPrimary, wallet.IsTestnet)
+		if err != nil {
+			return fmt.Errorf("failed to load wallet %s on chain %d: %w", wallet.Address, wallet.ChainID, err)
+		}
+		wallets[wallet.ID] = wallet
+	}
+
+	tokens := make(map[uuid.UUID]Token)
+	tokenIDs := make(map[uuid.UUID]uuid.UUID)
+	for _, token := range set.Tokens {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO tokens (id, address, chain_id, symbol, name, decimals)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (address, chain_id) DO UPDATE SET address = EXCLUDED.address
+			RETURNING id`,
+			token.ID, token.Address, token.ChainID, token.Symbol, token.Name, token.Decimals).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to load token %s on chain %d: %w", token.Symbol, token.ChainID, err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO token_prices_latest (token_id, price_usd, updated_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (token_id) DO UPDATE SET
+				price_usd = EXCLUDED.price_usd,
+				updated_at = EXCLUDED.updated_at`,
+			id, token.PriceUSD)
+		if err != nil {
+			return fmt.Errorf("failed to load %s price: %w", token.Symbol, err)
+		}
+		tokens[token.ID] = token
+		tokenIDs[token.ID] = id
+	}
+
+	for _, balance := range set.Balances {
+		token := tokens[balance.TokenID]
+		_, err := tx.Exec(ctx, `
+			INSERT INTO balances (wallet_id, token_id, balance, balance_usd)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (wallet_id, token_id) DO UPDATE SET
+				balance = EXCLUDED.balance,
+				balance_usd = EXCLUDED.balance_usd`,
+			balance.WalletID, tokenIDs[balance.TokenID], RawAmount(balance.Amount, token.Decimals), balance.Amount*token.PriceUSD)
+		if err != nil {
+			return fmt.Errorf("failed to load %s balance: %w", token.Symbol, err)
+		}
+	}
+
+	for _, t := range set.Transactions {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO transactions (
+				id, hash, chain_id, from_address, to_address, value,
+				gas_used, gas_price, gas_fee_usd, block_number,
+				timestamp, status, type, metadata
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 'confirmed', $12, $13)
+			ON CONFLICT (hash, chain_id) DO UPDATE SET
+				timestamp = EXCLUDED.timestamp,
+				block_number = EXCLUDED.block_number
+			RETURNING id`,
+			t.ID, t.Hash, t.ChainID, t.From, t.To, t.Value,
+			t.GasUsed, t.GasPrice, t.GasFeeUSD, t.Block,
+			t.Timestamp, t.Type, t.Metadata).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to load transaction %s: %w", t.Hash, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO user_transactions (user_id, transaction_id, chain_id, wallet_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, transaction_id) DO NOTHING`,
+			wallets[t.WalletID].UserID, id, t.ChainID, t.WalletID)
+		if err != nil {
+			return fmt.Errorf("failed to link transaction %s: %w", t.Hash, err)
+		}
+	}
+
+	pools := make(map[uuid.UUID]YieldPool)
+	poolIDs := make(map[uuid.UUID]uuid.UUID)
+	for _, pool := range set.YieldPools {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO yield_pools (
+				id, pool_id, protocol, protocol_id, pool_name, chain, chain_id, symbol,
+				tvl_usd, apy, apy_base, apy_reward, stable_coin, risk_level, is_active
+			) VALUES (
+				$1, $2, $3, (SELECT id FROM protocols WHERE slug = $4), $5, $6, $7, $8,
+				$9, $10, $11, $12, $13, $14, TRUE
+			)
+			ON CONFLICT (pool_id) DO UPDATE SET
+				tvl_usd = EXCLUDED.tvl_usd,
+				apy = EXCLUDED.apy,
+				apy_base = EXCLUDED.apy_base,
+				apy_reward = EXCLUDED.apy_reward,
+				is_active = TRUE
+			RETURNING id`,
+			pool.ID, pool.PoolID, pool.Protocol, pool.ProtocolSlug, pool.Name, pool.Chain, pool.ChainID, pool.Symbol,
+			pool.TVLUSD, pool.APYBase+pool.APYReward, pool.APYBase, pool.APYReward, pool.StableCoin, pool.RiskLevel).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to load pool %s: %w", pool.PoolID, err)
+		}
+		pools[pool.ID] = pool
+		poolIDs[pool.ID] = id
+	}
+
+	for _, position := range set.YieldPositions {
+		wallet := wallets[position.WalletID]
+		pool := pools[position.PoolID]
+		_, err := tx.Exec(ctx, `
+			INSERT INTO yield_positions (
+				id, user_id, wallet_id, pool_id, protocol_id, chain_id,
+				balance_usd, entry_price_usd, entry_transaction_hash, entry_time,
+				is_active, total_rewards_usd, current_value_usd, unrealized_pnl_usd
+			) VALUES (
+				$1, $2, $3, $4, (SELECT id FROM protocols WHERE slug = $5), $6,
+				$7, $8, $9, $10,
+				TRUE, $11, $7, $7 - $8
+			)
+			ON CONFLICT (id) DO UPDATE SET
+				balance_usd = EXCLUDED.balance_usd,
+				current_value_usd = EXCLUDED.current_value_usd,
+				unrealized_pnl_usd = EXCLUDED.unrealized_pnl_usd,
+				total_rewards_usd = EXCLUDED.total_rewards_usd,
+				entry_time = EXCLUDED.entry_time`,
+			position.ID, wallet.UserID, wallet.ID, poolIDs[position.PoolID], pool.ProtocolSlug, pool.ChainID,
+			position.CurrentUSD, position.EntryUSD, position.EntryTxHash, position.EntryTime,
+			position.RewardsUSD)
+		if err != nil {
+			return fmt.Errorf("failed to load %s position: %w", pool.PoolID, err)
+		}
+	}
+
+	for _, alert := range set.Alerts {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO alerts (id, user_id, type, target, conditions, notification)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (id) DO UPDATE SET
+				target = EXCLUDED.target,
+				conditions = EXCLUDED.conditions,
+				notification = EXCLUDED.notification`,
+			alert.ID, alert.UserID, alert.Type, alert.Target, alert.Conditions, alert.Notification)
+		if err != nil {
+			return fmt.Errorf("failed to load %s alert: %w", alert.Type, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reset removes set's users, along with everything that cascades from them, and its
+// transactions and pools. Shared rows such as tokens are kept.
+func Reset(ctx context.Context, db *pgxpool.Pool, set *Set) error {
+	var userIDs []uuid.UUID
+	for _, user := range set.Users {
+		userIDs = append(userIDs, user.ID)
+	}
+	var hashes []string
+	for _, t := range set.Transactions {
+		hashes = append(hashes, t.Hash)
+	}
+	var poolIDs []string
+	for _, pool := range set.YieldPools {
+		poolIDs = append(poolIDs, pool.PoolID)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = ANY($1)`, userIDs); err != nil {
+		return fmt.Errorf("failed to delete fixture users: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM transactions WHERE hash = ANY($1)`, hashes); err != nil {
+		return fmt.Errorf("failed to delete fixture transactions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM yield_pools WHERE pool_id = ANY($1)`, poolIDs); err != nil {
+		return fmt.Errorf("failed to delete fixture pools: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
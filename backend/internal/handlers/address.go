@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/errors"
+)
+
+// normalizeAddress validates an address taken from a request and returns its stored
+// form. 0x addresses must be well formed (with a valid checksum when mixed-case) and are
+// lowercased; other chains' addresses are passed on for their services to validate.
+func normalizeAddress(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.BadRequest("Address is required")
+	}
+	if !strings.HasPrefix(strings.ToLower(raw), "0x") {
+		return raw, nil
+	}
+
+	normalized, err := address.Parse(raw)
+	if err != nil {
+		return "", errors.BadRequest("Invalid address: " + err.Error())
+	}
+	return normalized, nil
+}
+
+// normalizeEVMAddress is normalizeAddress for endpoints that only serve EVM addresses
+func normalizeEVMAddress(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.BadRequest("Address is required")
+	}
+
+	normalized, err := address.Parse(raw)
+	if err != nil {
+		return "", errors.BadRequest("Invalid Ethereum address: " + err.Error())
+	}
+	return normalized, nil
+}
+
+// sameAddress reports whether two addresses are the same, ignoring EVM address case
+func sameAddress(a, b string) bool {
+	return address.Equal(a, b)
+}
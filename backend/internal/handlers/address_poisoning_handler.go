@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AddressPoisoningHandler struct {
+	poisoningService *services.AddressPoisoningService
+}
+
+func NewAddressPoisoningHandler(poisoningService *services.AddressPoisoningService) *AddressPoisoningHandler {
+	return &AddressPoisoningHandler{
+		poisoningService: poisoningService,
+	}
+}
+
+// GetPoisonedCounterparties handles GET /wallets/:walletId/poisoned-counterparties,
+// listing the lookalike addresses that sent the wallet zero-value transfers
+func (h *AddressPoisoningHandler) GetPoisonedCounterparties(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	counterparties, err := h.poisoningService.GetCounterparties(c.Context(), userID, walletID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, counterparties)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	// Every casing of the same address resolves to one stored form
+	for _, raw := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+		" 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed ",
+	} {
+		got, err := normalizeAddress(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", got)
+	}
+
+	// Other chains' addresses are left to their services
+	got, err := normalizeAddress("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq")
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", got)
+
+	for _, bad := range []string{"", "0x123", "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"} {
+		_, err := normalizeAddress(bad)
+		assert.Error(t, err, bad)
+	}
+
+	_, err = normalizeEVMAddress("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq")
+	assert.Error(t, err)
+	assert.True(t, sameAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+}
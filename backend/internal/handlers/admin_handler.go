@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	stderrors "errors"
 	"strconv"
 
 	"github.com/defi-dashboard/backend/internal/models"
@@ -42,14 +43,11 @@ func (h *AdminHandler) GetUsers(c *fiber.Ctx) error {
 	// For now, return empty response with proper structure
 	users := []models.User{}
 
-	return c.JSON(fiber.Map{
-		"data": users,
-		"meta": fiber.Map{
-			"page":       (offset / limit) + 1,
-			"limit":      limit,
-			"total":      len(users),
-			"totalPages": (len(users) + limit - 1) / limit,
-		},
+	return respondWithMeta(c, users, fiber.Map{
+		"page":       (offset / limit) + 1,
+		"limit":      limit,
+		"total":      len(users),
+		"totalPages": (len(users) + limit - 1) / limit,
 	})
 }
 
@@ -59,11 +57,8 @@ func (h *AdminHandler) GetErrors(c *fiber.Ctx) error {
 	// This would depend on how errors are logged in the system
 	errors := []interface{}{}
 
-	return c.JSON(fiber.Map{
-		"data": errors,
-		"meta": fiber.Map{
-			"total": len(errors),
-		},
+	return respondWithMeta(c, errors, fiber.Map{
+		"total": len(errors),
 	})
 }
 
@@ -77,7 +72,7 @@ func (h *AdminHandler) GetFeatureFlags(c *fiber.Ctx) error {
 		return errors.Internal("Failed to get feature flags")
 	}
 
-	return c.JSON(flags)
+	return respondLegacy(c, flags)
 }
 
 // CreateFeatureFlag handles POST /admin/feature-flags
@@ -110,7 +105,7 @@ func (h *AdminHandler) CreateFeatureFlag(c *fiber.Ctx) error {
 		return errors.Internal("Failed to create feature flag")
 	}
 
-	return c.Status(201).JSON(flag)
+	return respondLegacy(c.Status(201), flag)
 }
 
 // GetSystemBanners handles GET /admin/banners
@@ -125,7 +120,7 @@ func (h *AdminHandler) GetSystemBanners(c *fiber.Ctx) error {
 		return errors.Internal("Failed to get system banners")
 	}
 
-	return c.JSON(banners)
+	return respondLegacy(c, banners)
 }
 
 // CreateSystemBanner handles POST /admin/banners
@@ -164,7 +159,7 @@ func (h *AdminHandler) CreateSystemBanner(c *fiber.Ctx) error {
 		return errors.Internal("Failed to create system banner")
 	}
 
-	return c.Status(201).JSON(banner)
+	return respondLegacy(c.Status(201), banner)
 }
 
 // UpdateSystemBanner handles PUT /admin/banners/:id
@@ -182,7 +177,7 @@ func (h *AdminHandler) UpdateSystemBanner(c *fiber.Ctx) error {
 			"error", err.Error(),
 			"bannerID", bannerID,
 		)
-		if err.Error() == "system banner not found" {
+		if stderrors.Is(err, repos.ErrSystemBannerNotFound) {
 			return errors.NotFound("System banner")
 		}
 		return errors.Internal("Failed to get system banner")
@@ -223,7 +218,7 @@ func (h *AdminHandler) UpdateSystemBanner(c *fiber.Ctx) error {
 		return errors.Internal("Failed to update system banner")
 	}
 
-	return c.JSON(banner)
+	return respondLegacy(c, banner)
 }
 
 // DeleteSystemBanner handles DELETE /admin/banners/:id
@@ -240,7 +235,7 @@ func (h *AdminHandler) DeleteSystemBanner(c *fiber.Ctx) error {
 			"error", err.Error(),
 			"bannerID", bannerID,
 		)
-		if err.Error() == "system banner not found" {
+		if stderrors.Is(err, repos.ErrSystemBannerNotFound) {
 			return errors.NotFound("System banner")
 		}
 		return errors.Internal("Failed to delete system banner")
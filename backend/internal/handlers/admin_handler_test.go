@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +19,68 @@ import (
 )
 
 // Mock repositories for testing
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetByAddress(ctx context.Context, address string) (*models.User, error) {
+	args := m.Called(ctx, address)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, address, nonce string) (*models.User, error) {
+	args := m.Called(ctx, address, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateNonce(ctx context.Context, address, nonce string) (*models.User, error) {
+	args := m.Called(ctx, address, nonce)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, id uuid.UUID, lastLogin time.Time) error {
+	args := m.Called(ctx, id, lastLogin)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateEmail(ctx context.Context, id uuid.UUID, email string) (*models.User, error) {
+	args := m.Called(ctx, id, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 type MockFeatureFlagRepository struct {
 	mock.Mock
 }
@@ -70,6 +134,11 @@ func (m *MockSystemBannerRepository) Delete(ctx context.Context, id uuid.UUID) e
 	return args.Error(0)
 }
 
+func (m *MockSystemBannerRepository) GetActiveForUser(ctx context.Context, userID uuid.UUID) ([]models.SystemBanner, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.SystemBanner), args.Error(1)
+}
+
 func createTestAdminHandler() (*AdminHandler, *MockUserRepository, *MockFeatureFlagRepository, *MockSystemBannerRepository) {
 	mockUserRepo := new(MockUserRepository)
 	mockFlagRepo := new(MockFeatureFlagRepository)
@@ -79,7 +148,12 @@ func createTestAdminHandler() (*AdminHandler, *MockUserRepository, *MockFeatureF
 }
 
 func createTestAdminApp(handler *AdminHandler) *fiber.App {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return RespondError(c, appErr.Status, appErr)
+		}
+		return fiber.DefaultErrorHandler(c, err)
+	}})
 	
 	admin := app.Group("/admin")
 	admin.Get("/feature-flags", handler.GetFeatureFlags)
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AlchemyWebhookHandler struct {
+	webhookService *services.AlchemyWebhookService
+}
+
+func NewAlchemyWebhookHandler(webhookService *services.AlchemyWebhookService) *AlchemyWebhookHandler {
+	return &AlchemyWebhookHandler{webhookService: webhookService}
+}
+
+// HandleWebhook handles POST /webhooks/alchemy, the Notify deliveries of the address
+// activity and mined transaction webhooks, signed in X-Alchemy-Signature. The route is
+// off when no webhooks are configured.
+func (h *AlchemyWebhookHandler) HandleWebhook(c *fiber.Ctx) error {
+	if !h.webhookService.Enabled() {
+		return errors.NotFound("Route")
+	}
+
+	if err := h.webhookService.Receive(c.Context(), c.Body(), c.Get("X-Alchemy-Signature")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AlertBacktestHandler struct {
+	backtester *services.AlertBacktester
+}
+
+func NewAlertBacktestHandler(backtester *services.AlertBacktester) *AlertBacktestHandler {
+	return &AlertBacktestHandler{
+		backtester: backtester,
+	}
+}
+
+// Backtest handles POST /alerts/backtest. It replays an alert definition against the
+// stored price, APY or TVL history over the range and returns when it would have
+// triggered, without creating the alert.
+func (h *AlertBacktestHandler) Backtest(c *fiber.Ctx) error {
+	if _, ok := c.Locals("userID").(uuid.UUID); !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.AlertBacktestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+	if req.Type == "" {
+		return errors.BadRequest("Alert type is required")
+	}
+
+	result, err := h.backtester.Backtest(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+type AlertConfigHandler struct {
+	configService *services.AlertConfigService
+}
+
+func NewAlertConfigHandler(configService *services.AlertConfigService) *AlertConfigHandler {
+	return &AlertConfigHandler{
+		configService: configService,
+	}
+}
+
+// GetConfig handles GET /alerts/config, the user's managed alerts as a config. With
+// format=yaml it's written as YAML, ready to commit.
+func (h *AlertConfigHandler) GetConfig(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	config, err := h.configService.Export(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	if c.Query("format") == "yaml" {
+		data, err := alertConfigYAML(config)
+		if err != nil {
+			return errors.Internal("Failed to encode alert config")
+		}
+		c.Set("Content-Type", "application/yaml")
+		return c.Send(data)
+	}
+	return respond(c, config)
+}
+
+// ApplyConfig handles PUT /alerts/config, converging the user's managed alerts on the
+// config in the body, as JSON or, with a YAML content type, YAML. With dry_run=true it
+// only returns the plan.
+func (h *AlertConfigHandler) ApplyConfig(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	config, err := parseAlertConfig(c.Get(fiber.HeaderContentType), c.Body())
+	if err != nil {
+		return errors.BadRequest("Invalid alert config: " + err.Error())
+	}
+
+	plan, err := h.configService.Apply(c.Context(), userID, config, c.QueryBool("dry_run"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, plan)
+}
+
+// parseAlertConfig reads a config as JSON or YAML. YAML goes through JSON so the two
+// share field names.
+func parseAlertConfig(contentType string, body []byte) (*models.AlertConfig, error) {
+	if strings.Contains(contentType, "yaml") {
+		var generic interface{}
+		if err := yaml.Unmarshal(body, &generic); err != nil {
+			return nil, err
+		}
+		converted, err := json.Marshal(generic)
+		if err != nil {
+			return nil, err
+		}
+		body = converted
+	}
+
+	var config models.AlertConfig
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func alertConfigYAML(config *models.AlertConfig) ([]byte, error) {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(generic)
+}
\ No newline at end of file
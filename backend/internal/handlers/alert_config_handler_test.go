@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlertConfig(t *testing.T) {
+	yamlConfig := `
+alerts:
+  - key: eth/above
+    type: price_above
+    target:
+      type: token
+      identifier: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+      chainId: 1
+    conditions:
+      price: 4000
+    notification:
+      email: true
+`
+	config, err := parseAlertConfig("application/yaml", []byte(yamlConfig))
+	require.NoError(t, err)
+	require.Len(t, config.Alerts, 1)
+	entry := config.Alerts[0]
+	assert.Equal(t, "eth/above", entry.Key)
+	assert.Equal(t, 1, entry.Target.ChainID)
+	require.NotNil(t, entry.Conditions.Price)
+	assert.Equal(t, 4000.0, *entry.Conditions.Price)
+	assert.True(t, entry.Notification.Email)
+
+	// The YAML export reads back the same
+	exported, err := alertConfigYAML(config)
+	require.NoError(t, err)
+	again, err := parseAlertConfig("text/yaml; charset=utf-8", exported)
+	require.NoError(t, err)
+	assert.Equal(t, config, again)
+
+	config, err = parseAlertConfig("application/json", []byte(`{"alerts":[{"key":"a","type":"price_below"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []models.AlertConfigEntry{{Key: "a", Type: "price_below"}}, config.Alerts)
+
+	_, err = parseAlertConfig("application/yaml", []byte("alerts: [unclosed"))
+	assert.Error(t, err)
+}
\ No newline at end of file
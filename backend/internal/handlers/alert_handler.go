@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	stderrors "errors"
 	"strconv"
+	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/internal/services"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/defi-dashboard/backend/pkg/logger"
@@ -13,6 +16,8 @@ import (
 
 type AlertHandler struct {
 	alertService services.AlertService
+	// coverage fills in monitoring coverage on alert detail; nil leaves it out
+	coverage *services.AlertCoverageService
 }
 
 func NewAlertHandler(alertService services.AlertService) *AlertHandler {
@@ -21,6 +26,15 @@ func NewAlertHandler(alertService services.AlertService) *AlertHandler {
 	}
 }
 
+// NewAlertHandlerWithCoverage also reports on alert detail whether the alert was actually
+// being evaluated
+func NewAlertHandlerWithCoverage(alertService services.AlertService, coverage *services.AlertCoverageService) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+		coverage:     coverage,
+	}
+}
+
 // CreateAlert handles POST /alerts
 func (h *AlertHandler) CreateAlert(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
@@ -41,6 +55,9 @@ func (h *AlertHandler) CreateAlert(c *fiber.Ctx) error {
 
 	alert, err := h.alertService.CreateAlert(c.Context(), userID, &req)
 	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
 		logger.Error("Failed to create alert",
 			"error", err.Error(),
 			"userID", userID,
@@ -49,7 +66,7 @@ func (h *AlertHandler) CreateAlert(c *fiber.Ctx) error {
 		return errors.Internal("Failed to create alert")
 	}
 
-	return c.Status(201).JSON(alert)
+	return respondLegacy(c.Status(201), alert)
 }
 
 // GetAlerts handles GET /alerts
@@ -89,14 +106,11 @@ func (h *AlertHandler) GetAlerts(c *fiber.Ctx) error {
 	total := len(alerts)
 	totalPages := (total + limit - 1) / limit
 
-	return c.JSON(fiber.Map{
-		"data": alerts,
-		"meta": fiber.Map{
-			"page":       (offset / limit) + 1,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
+	return respondWithMeta(c, alerts, fiber.Map{
+		"page":       (offset / limit) + 1,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": totalPages,
 	})
 }
 
@@ -124,7 +138,27 @@ func (h *AlertHandler) GetAlert(c *fiber.Ctx) error {
 		return errors.NotFound("Alert")
 	}
 
-	return c.JSON(alert)
+	if h.coverage != nil {
+		var window time.Duration
+		if raw := c.Query("coverageHours"); raw != "" {
+			hours, err := strconv.Atoi(raw)
+			if err != nil {
+				return errors.BadRequest("Invalid coverageHours")
+			}
+			window = time.Duration(hours) * time.Hour
+		}
+		coverage, err := h.coverage.GetCoverage(c.Context(), alert, window)
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
+		if err != nil {
+			// Coverage is advisory; the alert itself is still worth returning
+			logger.Warn("Failed to get alert coverage", "error", err, "alertID", alertID)
+		}
+		alert.Coverage = coverage
+	}
+
+	return respondLegacy(c, alert)
 }
 
 // UpdateAlert handles PATCH /alerts/:alertId
@@ -148,18 +182,21 @@ func (h *AlertHandler) UpdateAlert(c *fiber.Ctx) error {
 
 	alert, err := h.alertService.UpdateAlert(c.Context(), alertID, userID, &req)
 	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
 		logger.Error("Failed to update alert",
 			"error", err.Error(),
 			"alertID", alertID,
 			"userID", userID,
 		)
-		if err.Error() == "alert not found" {
+		if stderrors.Is(err, repos.ErrAlertNotFound) {
 			return errors.NotFound("Alert")
 		}
 		return errors.Internal("Failed to update alert")
 	}
 
-	return c.JSON(alert)
+	return respondLegacy(c, alert)
 }
 
 // DeleteAlert handles DELETE /alerts/:alertId
@@ -183,7 +220,7 @@ func (h *AlertHandler) DeleteAlert(c *fiber.Ctx) error {
 			"alertID", alertID,
 			"userID", userID,
 		)
-		if err.Error() == "alert not found" {
+		if stderrors.Is(err, repos.ErrAlertNotFound) {
 			return errors.NotFound("Alert")
 		}
 		return errors.Internal("Failed to delete alert")
@@ -235,14 +272,11 @@ func (h *AlertHandler) GetAlertHistory(c *fiber.Ctx) error {
 	total := len(history)
 	totalPages := (total + limit - 1) / limit
 
-	return c.JSON(fiber.Map{
-		"data": history,
-		"meta": fiber.Map{
-			"page":       (offset / limit) + 1,
-			"limit":      limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
+	return respondWithMeta(c, history, fiber.Map{
+		"page":       (offset / limit) + 1,
+		"limit":      limit,
+		"total":      total,
+		"totalPages": totalPages,
 	})
 }
 
@@ -271,13 +305,13 @@ func (h *AlertHandler) PauseAlert(c *fiber.Ctx) error {
 			"alertID", alertID,
 			"userID", userID,
 		)
-		if err.Error() == "alert not found" {
+		if stderrors.Is(err, repos.ErrAlertNotFound) {
 			return errors.NotFound("Alert")
 		}
 		return errors.Internal("Failed to pause alert")
 	}
 
-	return c.JSON(alert)
+	return respondLegacy(c, alert)
 }
 
 // ActivateAlert handles PATCH /alerts/:alertId/activate
@@ -305,11 +339,90 @@ func (h *AlertHandler) ActivateAlert(c *fiber.Ctx) error {
 			"alertID", alertID,
 			"userID", userID,
 		)
-		if err.Error() == "alert not found" {
+		if stderrors.Is(err, repos.ErrAlertNotFound) {
 			return errors.NotFound("Alert")
 		}
 		return errors.Internal("Failed to activate alert")
 	}
 
-	return c.JSON(alert)
+	return respondLegacy(c, alert)
+}
+
+// MuteAlert handles PATCH /alerts/:alertId/mute
+func (h *AlertHandler) MuteAlert(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	alertIDStr := c.Params("alertId")
+	alertID, err := uuid.Parse(alertIDStr)
+	if err != nil {
+		return errors.BadRequest("Invalid alert ID")
+	}
+
+	var req models.MuteAlertRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errors.BadRequest("Invalid request body")
+		}
+	}
+
+	// An empty body unmutes the alert
+	var until *time.Time
+	switch {
+	case req.MutedUntil != nil && req.DurationMinutes != nil:
+		return errors.BadRequest("Specify either muted_until or duration_minutes, not both")
+	case req.DurationMinutes != nil:
+		if *req.DurationMinutes <= 0 {
+			return errors.BadRequest("duration_minutes must be greater than 0")
+		}
+		t := time.Now().Add(time.Duration(*req.DurationMinutes) * time.Minute)
+		until = &t
+	case req.MutedUntil != nil:
+		if !req.MutedUntil.After(time.Now()) {
+			return errors.BadRequest("muted_until must be in the future")
+		}
+		until = req.MutedUntil
+	}
+
+	alert, err := h.alertService.MuteAlert(c.Context(), alertID, userID, until)
+	if err != nil {
+		logger.Error("Failed to mute alert",
+			"error", err.Error(),
+			"alertID", alertID,
+			"userID", userID,
+		)
+		if stderrors.Is(err, repos.ErrAlertNotFound) {
+			return errors.NotFound("Alert")
+		}
+		return errors.Internal("Failed to mute alert")
+	}
+
+	return respondLegacy(c, alert)
+}
+
+// GetAlertStats handles GET /alerts/stats
+func (h *AlertHandler) GetAlertStats(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	period := c.Query("period", "7d")
+
+	stats, err := h.alertService.GetAlertStats(c.Context(), userID, period)
+	if err != nil {
+		if stderrors.Is(err, services.ErrInvalidStatsPeriod) {
+			return errors.BadRequest("Invalid period. Must be one of: 24h, 7d, 30d")
+		}
+		logger.Error("Failed to get alert stats",
+			"error", err.Error(),
+			"userID", userID,
+			"period", period,
+		)
+		return errors.Internal("Failed to get alert stats")
+	}
+
+	return respondLegacy(c, stats)
 }
\ No newline at end of file
@@ -8,8 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -57,8 +61,33 @@ func (m *MockAlertService) TriggerAlert(ctx context.Context, alertID uuid.UUID,
 	return args.Error(0)
 }
 
+func (m *MockAlertService) MuteAlert(ctx context.Context, alertID uuid.UUID, userID uuid.UUID, until *time.Time) (*models.Alert, error) {
+	args := m.Called(ctx, alertID, userID, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Alert), args.Error(1)
+}
+
+func (m *MockAlertService) GetAlertStats(ctx context.Context, userID uuid.UUID, period string) (*models.AlertStats, error) {
+	args := m.Called(ctx, userID, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AlertStats), args.Error(1)
+}
+
+// setupTestApp builds an app whose requests are all served from one pool of fiber
+// contexts. The mocks record those contexts, and asserting on a mock formats them, which
+// spoils the pooled context for the next request; so tests assert on mocks once they've
+// sent their last request.
 func setupTestApp() *fiber.App {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return RespondError(c, appErr.Status, appErr)
+		}
+		return fiber.DefaultErrorHandler(c, err)
+	}})
 	app.Use(func(c *fiber.Ctx) error {
 		// Mock auth middleware - set userID in locals
 		userID := uuid.New()
@@ -120,9 +149,6 @@ func TestAlertHandler_CreateAlert(t *testing.T) {
 		json.NewDecoder(resp.Body).Decode(&response)
 		assert.Equal(t, expectedAlert.Type, response.Type)
 		assert.Equal(t, expectedAlert.Status, response.Status)
-
-		// Verify mock
-		mockService.AssertExpectations(t)
 	})
 
 	t.Run("Invalid request body", func(t *testing.T) {
@@ -154,6 +180,9 @@ func TestAlertHandler_CreateAlert(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	})
+
+	// Verify mock
+	mockService.AssertExpectations(t)
 }
 
 func TestAlertHandler_GetAlerts(t *testing.T) {
@@ -206,9 +235,6 @@ func TestAlertHandler_GetAlerts(t *testing.T) {
 		meta := response["meta"].(map[string]interface{})
 		assert.Equal(t, float64(20), meta["limit"])
 		assert.Equal(t, float64(1), meta["page"])
-
-		// Verify mock
-		mockService.AssertExpectations(t)
 	})
 
 	t.Run("Get alerts with status filter", func(t *testing.T) {
@@ -267,9 +293,6 @@ func TestAlertHandler_GetAlert(t *testing.T) {
 		json.NewDecoder(resp.Body).Decode(&response)
 		assert.Equal(t, alertID, response.ID)
 		assert.Equal(t, expectedAlert.Type, response.Type)
-
-		// Verify mock
-		mockService.AssertExpectations(t)
 	})
 
 	t.Run("Invalid alert ID", func(t *testing.T) {
@@ -279,6 +302,9 @@ func TestAlertHandler_GetAlert(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	})
+
+	// Verify mock
+	mockService.AssertExpectations(t)
 }
 
 func TestAlertHandler_UpdateAlert(t *testing.T) {
@@ -393,9 +419,6 @@ func TestAlertHandler_PauseActivateAlert(t *testing.T) {
 		var response models.Alert
 		json.NewDecoder(resp.Body).Decode(&response)
 		assert.Equal(t, models.AlertStatusDisabled, response.Status)
-
-		// Verify mock
-		mockService.AssertExpectations(t)
 	})
 
 	t.Run("Activate alert", func(t *testing.T) {
@@ -429,4 +452,122 @@ func TestAlertHandler_PauseActivateAlert(t *testing.T) {
 		// Verify mock
 		mockService.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+func TestAlertHandler_MuteAlert(t *testing.T) {
+	mockService := new(MockAlertService)
+	handler := NewAlertHandler(mockService)
+
+	// The mock keeps each call's context, which fasthttp recycles for the app's next
+	// request, so every request gets an app of its own
+	newApp := func() *fiber.App {
+		app := setupTestApp()
+		app.Patch("/alerts/:alertId/mute", handler.MuteAlert)
+		return app
+	}
+
+	t.Run("Mute for a duration", func(t *testing.T) {
+		alertID := uuid.New()
+		mutedUntil := time.Now().Add(time.Hour)
+
+		expectedAlert := &models.Alert{
+			ID:         alertID,
+			MutedUntil: &mutedUntil,
+		}
+
+		// Setup mock
+		mockService.On("MuteAlert", mock.Anything, alertID, mock.AnythingOfType("uuid.UUID"), mock.MatchedBy(func(until *time.Time) bool {
+			return until != nil && until.After(time.Now().Add(59*time.Minute))
+		})).Return(expectedAlert, nil)
+
+		// Execute request
+		body := []byte(`{"duration_minutes": 60}`)
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/alerts/%s/mute", alertID.String()), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		// Assertions
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response models.Alert
+		json.NewDecoder(resp.Body).Decode(&response)
+		assert.NotNil(t, response.MutedUntil)
+
+		// Verify mock
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Empty body unmutes", func(t *testing.T) {
+		alertID := uuid.New()
+
+		// Setup mock
+		mockService.On("MuteAlert", mock.Anything, alertID, mock.AnythingOfType("uuid.UUID"), (*time.Time)(nil)).Return(&models.Alert{ID: alertID}, nil)
+
+		// Execute request
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/alerts/%s/mute", alertID.String()), nil)
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		// Assertions
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Verify mock
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Muted until in the past", func(t *testing.T) {
+		alertID := uuid.New()
+
+		body := []byte(`{"muted_until": "2020-01-01T00:00:00Z"}`)
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/alerts/%s/mute", alertID.String()), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Alert not found", func(t *testing.T) {
+		alertID := uuid.New()
+
+		// The service wraps the repository's sentinel
+		mockService.On("MuteAlert", mock.Anything, alertID, mock.AnythingOfType("uuid.UUID"), (*time.Time)(nil)).
+			Return(nil, fmt.Errorf("failed to mute alert: %w", repos.ErrAlertNotFound))
+
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/alerts/%s/mute", alertID.String()), nil)
+		resp, err := newApp().Test(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestAlertHandler_GetAlertStats(t *testing.T) {
+	mockService := new(MockAlertService)
+	handler := NewAlertHandler(mockService)
+
+	newApp := func() *fiber.App {
+		app := setupTestApp()
+		app.Get("/alerts/stats", handler.GetAlertStats)
+		return app
+	}
+
+	t.Run("Stats for a period", func(t *testing.T) {
+		mockService.On("GetAlertStats", mock.Anything, mock.AnythingOfType("uuid.UUID"), "30d").Return(&models.AlertStats{Period: "30d"}, nil)
+
+		resp, err := newApp().Test(httptest.NewRequest("GET", "/alerts/stats?period=30d", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		mockService.On("GetAlertStats", mock.Anything, mock.AnythingOfType("uuid.UUID"), "1y").
+			Return(nil, fmt.Errorf("%w: 1y", services.ErrInvalidStatsPeriod))
+
+		resp, err := newApp().Test(httptest.NewRequest("GET", "/alerts/stats?period=1y", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	mockService.AssertExpectations(t)
+}
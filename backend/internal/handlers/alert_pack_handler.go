@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AlertPackHandler struct {
+	packService *services.AlertPackService
+}
+
+func NewAlertPackHandler(packService *services.AlertPackService) *AlertPackHandler {
+	return &AlertPackHandler{
+		packService: packService,
+	}
+}
+
+// ExportAlerts handles POST /alerts/export, writing the listed alerts as a pack
+func (h *AlertPackHandler) ExportAlerts(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.ExportAlertPackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	pack, err := h.packService.Export(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, pack)
+}
+
+// ExportAlert handles GET /alerts/:alertId/export, writing one alert as a pack
+func (h *AlertPackHandler) ExportAlert(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	alertID, err := uuid.Parse(c.Params("alertId"))
+	if err != nil {
+		return errors.BadRequest("Invalid alert ID")
+	}
+
+	pack, err := h.packService.Export(c.Context(), userID, &models.ExportAlertPackRequest{
+		AlertIDs: []uuid.UUID{alertID},
+		Name:     c.Query("name", "Shared alert"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return respond(c, pack)
+}
+
+// ImportAlerts handles POST /alerts/import
+func (h *AlertPackHandler) ImportAlerts(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.ImportAlertPackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	result, err := h.packService.Import(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), result)
+}
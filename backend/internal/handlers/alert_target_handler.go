@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AlertTargetHandler struct {
+	targetService *services.AlertTargetService
+}
+
+func NewAlertTargetHandler(targetService *services.AlertTargetService) *AlertTargetHandler {
+	return &AlertTargetHandler{
+		targetService: targetService,
+	}
+}
+
+// GetSuccessors handles GET /alerts/:alertId/successors, the live pools or tokens an
+// alert expired for its target could be re-pointed to
+func (h *AlertTargetHandler) GetSuccessors(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	alertID, err := uuidParam(c, "alertId", "alert")
+	if err != nil {
+		return err
+	}
+
+	successors, err := h.targetService.GetSuccessors(c.Context(), userID, alertID)
+	if err != nil {
+		return err
+	}
+	return respond(c, successors)
+}
+
+// Repoint handles POST /alerts/:alertId/repoint, moving an alert expired for its
+// target to a successor and making it active again
+func (h *AlertTargetHandler) Repoint(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	alertID, err := uuidParam(c, "alertId", "alert")
+	if err != nil {
+		return err
+	}
+
+	var req models.RepointAlertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	alert, err := h.targetService.Repoint(c.Context(), userID, alertID, &req)
+	if err != nil {
+		return err
+	}
+	return respond(c, alert)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AllowanceHandler struct {
+	allowanceService *services.AllowanceService
+}
+
+func NewAllowanceHandler(allowanceService *services.AllowanceService) *AllowanceHandler {
+	return &AllowanceHandler{
+		allowanceService: allowanceService,
+	}
+}
+
+// VerifyAllowances handles GET /allowances/verify?wallet=&token=&spender=&chainId=,
+// reading the wallet's allowances on chain and updating the stored ones. chainId is
+// mainnet by default.
+func (h *AllowanceHandler) VerifyAllowances(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	wallet, err := normalizeAddress(c.Query("wallet"))
+	if err != nil {
+		return err
+	}
+	chainID := 1
+	if requested, err := optionalChainIDQuery(c); err != nil {
+		return err
+	} else if requested != nil {
+		chainID = *requested
+	}
+
+	verification, err := h.allowanceService.Verify(c.Context(), userID, wallet, chainID,
+		c.Query("token"), c.Query("spender"), providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return respond(c, verification)
+}
\ No newline at end of file
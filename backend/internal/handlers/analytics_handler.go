@@ -1,32 +1,39 @@
 package handlers
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
 	"github.com/defi-dashboard/backend/pkg/pnl"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type AnalyticsHandler struct {
-	pnlService  pnl.Service
-	csvExporter *pnl.CSVExporter
+	pnlService    pnl.Service
+	csvExporter   *pnl.CSVExporter
+	uploadService *services.UploadService
 }
 
-func NewAnalyticsHandler(pnlService pnl.Service, csvExporter *pnl.CSVExporter) *AnalyticsHandler {
+func NewAnalyticsHandler(pnlService pnl.Service, csvExporter *pnl.CSVExporter, uploadService *services.UploadService) *AnalyticsHandler {
 	return &AnalyticsHandler{
-		pnlService:  pnlService,
-		csvExporter: csvExporter,
+		pnlService:    pnlService,
+		csvExporter:   csvExporter,
+		uploadService: uploadService,
 	}
 }
 
 // GetPnL handles GET /analytics/pnl/:address
 func (h *AnalyticsHandler) GetPnL(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse query parameters
@@ -36,7 +43,6 @@ func (h *AnalyticsHandler) GetPnL(c *fiber.Ctx) error {
 
 	// Parse dates
 	var from, to time.Time
-	var err error
 
 	if fromStr != "" {
 		from, err = time.Parse("2006-01-02", fromStr)
@@ -69,8 +75,13 @@ func (h *AnalyticsHandler) GetPnL(c *fiber.Ctx) error {
 		return errors.BadRequest("Invalid method. Use 'fifo' or 'lifo'")
 	}
 
+	washSales, err := washSaleRule(c)
+	if err != nil {
+		return err
+	}
+
 	// Calculate PnL
-	calculation, err := h.pnlService.CalculatePnL(c.Context(), address, from, to, method)
+	calculation, err := h.pnlService.CalculatePnL(c.Context(), address, from, to, method, washSales)
 	if err != nil {
 		logger.Error("Failed to calculate PnL",
 			"error", err.Error(),
@@ -80,15 +91,15 @@ func (h *AnalyticsHandler) GetPnL(c *fiber.Ctx) error {
 		return errors.Internal("Failed to calculate PnL")
 	}
 
-	return c.JSON(calculation)
+	return respondLegacy(c, calculation)
 }
 
 // ExportPnL handles GET /analytics/export
 func (h *AnalyticsHandler) ExportPnL(c *fiber.Ctx) error {
 	// Parse query parameters
-	address := c.Query("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Query("address"))
+	if err != nil {
+		return err
 	}
 
 	fromStr := c.Query("from")
@@ -98,7 +109,6 @@ func (h *AnalyticsHandler) ExportPnL(c *fiber.Ctx) error {
 
 	// Parse dates
 	var from, to time.Time
-	var err error
 
 	if fromStr != "" {
 		from, err = time.Parse("2006-01-02", fromStr)
@@ -131,13 +141,18 @@ func (h *AnalyticsHandler) ExportPnL(c *fiber.Ctx) error {
 		return errors.BadRequest("Invalid method. Use 'fifo' or 'lifo'")
 	}
 
+	washSales, err := washSaleRule(c)
+	if err != nil {
+		return err
+	}
+
 	// Validate format
 	if formatStr != "csv" {
 		return errors.BadRequest("Only CSV format is currently supported")
 	}
 
 	// Get export data
-	exportData, err := h.pnlService.GetPnLExportData(c.Context(), address, from, to, method)
+	exportData, err := h.pnlService.GetPnLExportData(c.Context(), address, from, to, method, washSales)
 	if err != nil {
 		logger.Error("Failed to get PnL export data",
 			"error", err.Error(),
@@ -162,9 +177,9 @@ func (h *AnalyticsHandler) ExportPnL(c *fiber.Ctx) error {
 
 		return h.csvExporter.ExportToWriter(c.Response().BodyWriter(), exportData)
 	} else {
-		// Create temporary file and return download URL
-		filepath, err := h.csvExporter.ExportToCSV(exportData, address)
-		if err != nil {
+		// Store the file and return a signed download URL
+		var buf bytes.Buffer
+		if err := h.csvExporter.ExportToWriter(&buf, exportData); err != nil {
 			logger.Error("Failed to create CSV file",
 				"error", err.Error(),
 				"address", address,
@@ -172,47 +187,55 @@ func (h *AnalyticsHandler) ExportPnL(c *fiber.Ctx) error {
 			return errors.Internal("Failed to create CSV file")
 		}
 
-		// Schedule cleanup after 1 hour
-		h.csvExporter.ScheduleCleanup(filepath, time.Hour)
+		userID := c.Locals("userID").(uuid.UUID)
+		filename := fmt.Sprintf("pnl_export_%s_%s.csv", address[:8], time.Now().Format("20060102_150405"))
+		upload, err := h.uploadService.Save(c.Context(), userID, models.UploadKindExport, filename, "text/csv", buf.Bytes())
+		if err != nil {
+			return err
+		}
+		link, err := h.uploadService.Link(c.Context(), upload, 0)
+		if err != nil {
+			return err
+		}
 
 		// Return download info
-		return c.JSON(fiber.Map{
-			"download_url": fmt.Sprintf("/analytics/download?file=%s", filepath),
-			"expires_at":   time.Now().Add(time.Hour).Unix(),
-			"filename":     fmt.Sprintf("pnl_export_%s_%s.csv", address[:8], time.Now().Format("20060102_150405")),
+		return respondLegacy(c, fiber.Map{
+			"upload_id":    upload.ID,
+			"download_url": link.DownloadURL,
+			"expires_at":   link.URLExpiresAt.Unix(),
+			"filename":     filename,
 			"record_count": len(exportData),
 		})
 	}
 }
 
-// DownloadFile handles GET /analytics/download for file downloads
-func (h *AnalyticsHandler) DownloadFile(c *fiber.Ctx) error {
-	filepath := c.Query("file")
-	if filepath == "" {
-		return errors.BadRequest("File parameter is required")
+// washSaleRule reads the jurisdiction whose wash-sale rule applies and an optional
+// wash_sale_days overriding its window
+func washSaleRule(c *fiber.Ctx) (*pnl.WashSaleRule, error) {
+	windowDays := 0
+	if daysStr := c.Query("wash_sale_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > 365 {
+			return nil, errors.BadRequest("wash_sale_days must be between 1 and 365")
+		}
+		windowDays = days
 	}
 
-	// Security check: only allow files from temp directory
-	// This is a basic check - in production, you'd want more robust validation
-	if !isValidTempFile(filepath) {
-		return errors.BadRequest("Invalid file path")
+	rule, ok := pnl.WashSaleRuleFor(c.Query("jurisdiction"), windowDays)
+	if !ok {
+		return nil, errors.BadRequest("Invalid jurisdiction. Use 'us', 'ca', 'uk' or 'none'")
 	}
-
-	return c.SendFile(filepath)
-}
-
-// Helper function to validate temp file paths
-func isValidTempFile(filepath string) bool {
-	// Basic validation - in production, implement proper path validation
-	// to prevent directory traversal attacks
-	return filepath != "" && len(filepath) > 0
+	if rule == nil && windowDays > 0 {
+		return nil, errors.BadRequest("wash_sale_days requires a jurisdiction")
+	}
+	return rule, nil
 }
 
 // GetPnLSummary handles GET /analytics/summary/:address for dashboard display
 func (h *AnalyticsHandler) GetPnLSummary(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Get summary for the last 30 days by default
@@ -233,8 +256,8 @@ func (h *AnalyticsHandler) GetPnLSummary(c *fiber.Ctx) error {
 	}
 
 	// Calculate PnL for both FIFO and LIFO
-	fifoCalc, fifoErr := h.pnlService.CalculatePnL(c.Context(), address, from, to, pnl.FIFO)
-	lifoCalc, lifoErr := h.pnlService.CalculatePnL(c.Context(), address, from, to, pnl.LIFO)
+	fifoCalc, fifoErr := h.pnlService.CalculatePnL(c.Context(), address, from, to, pnl.FIFO, nil)
+	lifoCalc, lifoErr := h.pnlService.CalculatePnL(c.Context(), address, from, to, pnl.LIFO, nil)
 
 	summary := fiber.Map{
 		"address": address,
@@ -256,5 +279,84 @@ func (h *AnalyticsHandler) GetPnLSummary(c *fiber.Ctx) error {
 		summary["lifo_error"] = lifoErr.Error()
 	}
 
-	return c.JSON(summary)
-}
\ No newline at end of file
+	return respondLegacy(c, summary)
+}
+
+// GetNFTPnL handles GET /analytics/nft-pnl/:address
+func (h *AnalyticsHandler) GetNFTPnL(c *fiber.Ctx) error {
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	// Default to the last year
+	from := time.Now().AddDate(-1, 0, 0)
+	to := time.Now()
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsedFrom, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return errors.BadRequest("Invalid from date format. Use YYYY-MM-DD")
+		}
+		from = parsedFrom
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsedTo, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return errors.BadRequest("Invalid to date format. Use YYYY-MM-DD")
+		}
+		to = parsedTo
+	}
+
+	summary, err := h.pnlService.CalculateNFTPnL(c.Context(), address, from, to)
+	if err != nil {
+		logger.Error("Failed to calculate NFT PnL",
+			"error", err.Error(),
+			"address", address,
+		)
+		return errors.Internal("Failed to calculate NFT PnL")
+	}
+
+	return respondLegacy(c, summary)
+}
+
+// GetIncome handles GET /analytics/income/:address
+func (h *AnalyticsHandler) GetIncome(c *fiber.Ctx) error {
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	// Default to the last year
+	from := time.Now().AddDate(-1, 0, 0)
+	to := time.Now()
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsedFrom, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return errors.BadRequest("Invalid from date format. Use YYYY-MM-DD")
+		}
+		from = parsedFrom
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsedTo, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return errors.BadRequest("Invalid to date format. Use YYYY-MM-DD")
+		}
+		to = parsedTo
+	}
+
+	summary, err := h.pnlService.CalculateIncome(c.Context(), address, from, to)
+	if err != nil {
+		logger.Error("Failed to calculate income",
+			"error", err.Error(),
+			"address", address,
+		)
+		return errors.Internal("Failed to calculate income")
+	}
+
+	return respondLegacy(c, summary)
+}
+
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	stderrors "errors"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// GetAPIKeys handles GET /api-keys
+func (h *APIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	keys, err := h.apiKeyService.ListKeys(c.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to get api keys",
+			"error", err.Error(),
+			"userID", userID,
+		)
+		return errors.Internal("Failed to get API keys")
+	}
+
+	if keys == nil {
+		keys = []models.UserAPIKey{}
+	}
+
+	return respond(c, keys)
+}
+
+// SetAPIKey handles PUT /api-keys/:provider
+func (h *APIKeyHandler) SetAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.SetUserAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	if req.APIKey == "" {
+		return errors.BadRequest("api_key is required")
+	}
+
+	key, err := h.apiKeyService.SetKey(c.Context(), userID, c.Params("provider"), req.APIKey)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
+		logger.Error("Failed to store api key",
+			"error", err.Error(),
+			"userID", userID,
+			"provider", c.Params("provider"),
+		)
+		return errors.Internal("Failed to store API key")
+	}
+
+	return respondLegacy(c, key)
+}
+
+// DeleteAPIKey handles DELETE /api-keys/:provider
+func (h *APIKeyHandler) DeleteAPIKey(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	err := h.apiKeyService.DeleteKey(c.Context(), userID, c.Params("provider"))
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
+		if stderrors.Is(err, repos.ErrAPIKeyNotFound) {
+			return errors.NotFound("API key")
+		}
+		logger.Error("Failed to delete api key",
+			"error", err.Error(),
+			"userID", userID,
+			"provider", c.Params("provider"),
+		)
+		return errors.Internal("Failed to delete API key")
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/attestation"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AttestationHandler struct {
+	attestationService *services.AttestationService
+	uploadService      *services.UploadService
+}
+
+func NewAttestationHandler(attestationService *services.AttestationService, uploadService *services.UploadService) *AttestationHandler {
+	return &AttestationHandler{
+		attestationService: attestationService,
+		uploadService:      uploadService,
+	}
+}
+
+// CreateAttestation handles POST /attestations: it signs the holdings of the user's
+// wallets, or of a wallet group's, at pinned blocks and stores the attestation as an
+// export
+func (h *AttestationHandler) CreateAttestation(c *fiber.Ctx) error {
+	if !h.attestationService.Enabled() {
+		return errors.NotFound("Route")
+	}
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateAttestationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errors.BadRequest("Invalid request body")
+		}
+	}
+
+	keys := providerKeys(c)
+	signed, err := h.attestationService.Create(c.Context(), userID, req.WalletGroupID, keys.Alchemy, keys.CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return errors.Internal("Failed to encode attestation")
+	}
+	filename := fmt.Sprintf("attestation_%s_%s.json", signed.KeyID, time.Now().Format("20060102_150405"))
+	upload, err := h.uploadService.Save(c.Context(), userID, models.UploadKindExport, filename, "application/json", data)
+	if err != nil {
+		return err
+	}
+	link, err := h.uploadService.Link(c.Context(), upload, 0)
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c.Status(fiber.StatusCreated), fiber.Map{
+		"upload_id":    upload.ID,
+		"download_url": link.DownloadURL,
+		"expires_at":   link.URLExpiresAt.Unix(),
+		"filename":     filename,
+		"attestation":  signed,
+	})
+}
+
+// GetPublicKey handles GET /attestations/public-key, the key third parties verify
+// attestations with
+func (h *AttestationHandler) GetPublicKey(c *fiber.Ctx) error {
+	if !h.attestationService.Enabled() {
+		return errors.NotFound("Route")
+	}
+	signer := h.attestationService.Signer()
+	return respondLegacy(c, fiber.Map{
+		"algorithm":  attestation.Algorithm,
+		"key_id":     signer.KeyID(),
+		"public_key": base64.StdEncoding.EncodeToString(signer.PublicKey()),
+	})
+}
+
+// VerifyAttestation handles POST /attestations/verify, checking an exported attestation
+// was signed by this server and not altered since
+func (h *AttestationHandler) VerifyAttestation(c *fiber.Ctx) error {
+	if !h.attestationService.Enabled() {
+		return errors.NotFound("Route")
+	}
+
+	var signed attestation.Attestation
+	if err := json.Unmarshal(c.Body(), &signed); err != nil {
+		return errors.BadRequest("Invalid attestation")
+	}
+
+	if err := h.attestationService.Verify(&signed); err != nil {
+		return respondLegacy(c, fiber.Map{"valid": false, "error": err.Error()})
+	}
+	return respondLegacy(c, fiber.Map{"valid": true, "key_id": signed.KeyID})
+}
\ No newline at end of file
@@ -9,7 +9,9 @@ import (
 	"github.com/defi-dashboard/backend/internal/middleware"
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
 	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -19,6 +21,8 @@ type AuthHandler struct {
 	siweService *services.SIWEService
 	jwtSecret   string
 	jwtExpiry   int
+	// referralService credits the referral code new users sign up with, if set
+	referralService *services.ReferralService
 }
 
 func NewAuthHandler(authService *services.AuthService, siweService *services.SIWEService, jwtSecret string, jwtExpiry int) *AuthHandler {
@@ -30,6 +34,11 @@ func NewAuthHandler(authService *services.AuthService, siweService *services.SIW
 	}
 }
 
+// SetReferrals lets users sign up with a referral code
+func (h *AuthHandler) SetReferrals(referralService *services.ReferralService) {
+	h.referralService = referralService
+}
+
 // GetNonce handles POST /auth/siwe/nonce
 func (h *AuthHandler) GetNonce(c *fiber.Ctx) error {
 	var req NonceRequest
@@ -46,6 +55,14 @@ func (h *AuthHandler) GetNonce(c *fiber.Ctx) error {
 		return errors.BadRequest("Invalid Ethereum address")
 	}
 
+	chainID := req.ChainID
+	if chainID == 0 {
+		chainID = 1
+	}
+	if !blockchain.IsSupportedChain(chainID) {
+		return errors.BadRequest("Unsupported chain")
+	}
+
 	// Generate nonce
 	nonce, err := h.siweService.GenerateNonce(c.Context(), req.Address)
 	if err != nil {
@@ -53,12 +70,12 @@ func (h *AuthHandler) GetNonce(c *fiber.Ctx) error {
 	}
 
 	// Generate SIWE message
-	message, err := h.siweService.GenerateSIWEMessage(req.Address, nonce)
+	message, err := h.siweService.GenerateSIWEMessageForChain(req.Address, nonce, chainID)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(NonceResponse{
+	return respondLegacy(c, NonceResponse{
 		Nonce:   nonce,
 		Message: message,
 	})
@@ -82,13 +99,20 @@ func (h *AuthHandler) Verify(c *fiber.Ctx) error {
 		return err
 	}
 
+	// A code that can't be credited doesn't stop the sign in
+	if req.ReferralCode != "" && h.referralService != nil {
+		if _, err := h.referralService.Claim(c.Context(), user.ID, req.ReferralCode); err != nil {
+			logger.Warn("Referral code not credited", "userId", user.ID, "error", err)
+		}
+	}
+
 	// Generate JWT token
 	token, err := h.generateJWT(user)
 	if err != nil {
 		return errors.Internal("Failed to generate token")
 	}
 
-	return c.JSON(AuthResponse{
+	return respondLegacy(c, AuthResponse{
 		Token:     token,
 		ExpiresIn: h.jwtExpiry * 3600, // Convert hours to seconds
 		Address:   user.Address,
@@ -110,7 +134,7 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 		return errors.NotFound("User not found")
 	}
 
-	return c.JSON(UserProfileResponse{
+	return respondLegacy(c, UserProfileResponse{
 		User:    user,
 		Address: user.Address,
 	})
@@ -137,7 +161,7 @@ func (h *AuthHandler) SendMagicLink(c *fiber.Ctx) error {
 	magicLink := fmt.Sprintf("https://your-domain.com/auth/verify-magic?token=%s", magicToken)
 	
 	// In development, return the link; in production, just return success
-	return c.JSON(MagicLinkResponse{
+	return respondLegacy(c, MagicLinkResponse{
 		Message:   "Magic link sent to your email",
 		MagicLink: magicLink, // Remove this in production
 	})
@@ -162,6 +186,9 @@ func (h *AuthHandler) generateJWT(user *models.User) (string, error) {
 
 type NonceRequest struct {
 	Address string `json:"address" validate:"required"`
+	// ChainID is the chain the message is signed for, mainnet by default. Contract
+	// wallets sign in with a chain they're deployed on.
+	ChainID int `json:"chainId"`
 }
 
 type NonceResponse struct {
@@ -172,6 +199,8 @@ type NonceResponse struct {
 type VerifyRequest struct {
 	Message   string `json:"message" validate:"required"`
 	Signature string `json:"signature" validate:"required"`
+	// ReferralCode credits another user with the signup, within a week of it
+	ReferralCode string `json:"referral_code,omitempty"`
 }
 
 type AuthResponse struct {
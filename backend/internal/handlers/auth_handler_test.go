@@ -4,78 +4,68 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-// Mock services
-type MockAuthService struct {
+type MockNonceRepository struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) GenerateNonce(ctx context.Context, address string) (string, error) {
-	args := m.Called(ctx, address)
-	return args.String(0), args.Error(1)
+func (m *MockNonceRepository) Store(ctx context.Context, address, nonce string, expiresAt time.Time) error {
+	args := m.Called(ctx, address, nonce, expiresAt)
+	return args.Error(0)
 }
 
-func (m *MockAuthService) VerifySignature(ctx context.Context, message map[string]interface{}, signature string) (string, error) {
-	args := m.Called(ctx, message, signature)
-	return args.String(0), args.Error(1)
+func (m *MockNonceRepository) ValidateAndUse(ctx context.Context, address, nonce string) (bool, error) {
+	args := m.Called(ctx, address, nonce)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockAuthService) ValidateToken(tokenString string) (*models.User, error) {
-	args := m.Called(tokenString)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.User), args.Error(1)
+func (m *MockNonceRepository) CleanupExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
 }
 
-func (m *MockAuthService) GetUserByAddress(ctx context.Context, address string) (*models.User, error) {
-	args := m.Called(ctx, address)
+func (m *MockNonceRepository) GetByAddressAndNonce(ctx context.Context, address, nonce string) (*models.NonceStorage, error) {
+	args := m.Called(ctx, address, nonce)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.User), args.Error(1)
-}
-
-type MockSIWEService struct {
-	mock.Mock
+	return args.Get(0).(*models.NonceStorage), args.Error(1)
 }
 
-func (m *MockSIWEService) GenerateNonce(ctx context.Context, address string) (string, error) {
-	args := m.Called(ctx, address)
-	return args.String(0), args.Error(1)
+// createTestAuthHandler builds the handler on real SIWE and auth services over mock repositories
+func createTestAuthHandler() (*AuthHandler, *services.SIWEService, *MockUserRepository, *MockNonceRepository) {
+	userRepo := new(MockUserRepository)
+	nonceRepo := new(MockNonceRepository)
+	siweService := services.NewSIWEService(userRepo, nonceRepo, "localhost")
+	authService := services.NewAuthService(userRepo, nil, "test-secret", 24)
+	return NewAuthHandler(authService, siweService, "test-secret", 24), siweService, userRepo, nonceRepo
 }
 
-func (m *MockSIWEService) VerifySignature(ctx context.Context, message, signature string) (*models.User, error) {
-	args := m.Called(ctx, message, signature)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.User), args.Error(1)
-}
-
-func (m *MockSIWEService) GenerateSIWEMessage(address, nonce string) (string, error) {
-	args := m.Called(address, nonce)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockSIWEService) ValidateAddress(address string) bool {
-	args := m.Called(address)
-	return args.Bool(0)
-}
-
-func setupTestApp() *fiber.App {
-	app := fiber.New()
+// setupAuthTestApp builds an app that writes errors as the API does. As with
+// setupTestApp, tests assert on mocks after their last request.
+func setupAuthTestApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return RespondError(c, appErr.Status, appErr)
+		}
+		return fiber.DefaultErrorHandler(c, err)
+	}})
 	app.Use(func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "application/json")
 		return c.Next()
@@ -84,21 +74,16 @@ func setupTestApp() *fiber.App {
 }
 
 func TestAuthHandler_GetNonce(t *testing.T) {
-	app := setupTestApp()
-	authService := new(MockAuthService)
-	siweService := new(MockSIWEService)
-	
-	handler := NewAuthHandler(authService, siweService, "test-secret", 24)
+	app := setupAuthTestApp()
+	handler, _, _, nonceRepo := createTestAuthHandler()
 	app.Post("/nonce", handler.GetNonce)
 
 	t.Run("Valid address", func(t *testing.T) {
 		address := "0x742d35Cc6573C42c8Ee90b4E43e04c1Fe9E2395d"
-		testNonce := "test-nonce-123"
-		testMessage := "localhost wants you to sign in with your Ethereum account"
-
-		siweService.On("ValidateAddress", address).Return(true)
-		siweService.On("GenerateNonce", mock.Anything, address).Return(testNonce, nil)
-		siweService.On("GenerateSIWEMessage", address, testNonce).Return(testMessage, nil)
+		var storedNonce string
+		nonceRepo.On("Store", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+			Run(func(args mock.Arguments) { storedNonce = args.String(2) }).
+			Return(nil)
 
 		reqBody := NonceRequest{Address: address}
 		body, _ := json.Marshal(reqBody)
@@ -112,17 +97,14 @@ func TestAuthHandler_GetNonce(t *testing.T) {
 
 		var response NonceResponse
 		json.NewDecoder(resp.Body).Decode(&response)
-		assert.Equal(t, testNonce, response.Nonce)
-		assert.Equal(t, testMessage, response.Message)
-
-		siweService.AssertExpectations(t)
+		assert.Equal(t, storedNonce, response.Nonce)
+		assert.Contains(t, response.Message, "localhost wants you to sign in with your Ethereum account")
+		assert.Contains(t, response.Message, "Nonce: "+storedNonce)
 	})
 
 	t.Run("Invalid address", func(t *testing.T) {
 		address := "invalid-address"
 
-		siweService.On("ValidateAddress", address).Return(false)
-
 		reqBody := NonceRequest{Address: address}
 		body, _ := json.Marshal(reqBody)
 
@@ -145,25 +127,36 @@ func TestAuthHandler_GetNonce(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 400, resp.StatusCode)
 	})
+
+	nonceRepo.AssertExpectations(t)
 }
 
 func TestAuthHandler_Verify(t *testing.T) {
-	app := setupTestApp()
-	authService := new(MockAuthService)
-	siweService := new(MockSIWEService)
-	
-	handler := NewAuthHandler(authService, siweService, "test-secret", 24)
+	app := setupAuthTestApp()
+	handler, siweService, userRepo, nonceRepo := createTestAuthHandler()
 	app.Post("/verify", handler.Verify)
 
 	t.Run("Valid verification", func(t *testing.T) {
-		testMessage := "localhost wants you to sign in with your Ethereum account"
-		testSignature := "0x1234567890abcdef"
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+		testMessage, err := siweService.GenerateSIWEMessage(address, "testnonce12345678")
+		require.NoError(t, err)
+
+		hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(testMessage), testMessage)))
+		signature, err := crypto.Sign(hash, key)
+		require.NoError(t, err)
+		signature[64] += 27
+		testSignature := hexutil.Encode(signature)
+
 		testUser := &models.User{
 			ID:      uuid.New(),
-			Address: "0x742d35Cc6573C42c8Ee90b4E43e04c1Fe9E2395d",
+			Address: address,
 		}
 
-		siweService.On("VerifySignature", mock.Anything, testMessage, testSignature).Return(testUser, nil)
+		nonceRepo.On("ValidateAndUse", mock.Anything, address, "testnonce12345678").Return(true, nil)
+		userRepo.On("GetByAddress", mock.Anything, address).Return(testUser, nil)
+		userRepo.On("UpdateLastLogin", mock.Anything, testUser.ID, mock.AnythingOfType("time.Time")).Return(nil)
 
 		reqBody := VerifyRequest{
 			Message:   testMessage,
@@ -183,8 +176,6 @@ func TestAuthHandler_Verify(t *testing.T) {
 		assert.NotEmpty(t, response.Token)
 		assert.Equal(t, testUser.Address, response.Address)
 		assert.Equal(t, 24*3600, response.ExpiresIn) // 24 hours in seconds
-
-		siweService.AssertExpectations(t)
 	})
 
 	t.Run("Missing message", func(t *testing.T) {
@@ -214,14 +205,14 @@ func TestAuthHandler_Verify(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 400, resp.StatusCode)
 	})
+
+	nonceRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
 }
 
 func TestAuthHandler_SendMagicLink(t *testing.T) {
-	app := setupTestApp()
-	authService := new(MockAuthService)
-	siweService := new(MockSIWEService)
-	
-	handler := NewAuthHandler(authService, siweService, "test-secret", 24)
+	app := setupAuthTestApp()
+	handler, _, _, _ := createTestAuthHandler()
 	app.Post("/magic-link", handler.SendMagicLink)
 
 	t.Run("Valid email", func(t *testing.T) {
@@ -257,10 +248,7 @@ func TestAuthHandler_SendMagicLink(t *testing.T) {
 }
 
 func TestAuthHandler_GenerateJWT(t *testing.T) {
-	authService := new(MockAuthService)
-	siweService := new(MockSIWEService)
-	
-	handler := NewAuthHandler(authService, siweService, "test-secret", 24)
+	handler, _, _, _ := createTestAuthHandler()
 
 	testUser := &models.User{
 		ID:        uuid.New(),
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BalanceRefreshHandler struct {
+	balanceRefreshService *services.BalanceRefreshService
+}
+
+func NewBalanceRefreshHandler(balanceRefreshService *services.BalanceRefreshService) *BalanceRefreshHandler {
+	return &BalanceRefreshHandler{
+		balanceRefreshService: balanceRefreshService,
+	}
+}
+
+// CreateBalanceRefresh handles POST /admin/balance-refreshes, queuing a refresh of the
+// listed wallets' balances, or of every wallet's, for the worker
+func (h *BalanceRefreshHandler) CreateBalanceRefresh(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateBalanceRefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	refresh, err := h.balanceRefreshService.Create(c.Context(), adminID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), refresh)
+}
+
+// GetBalanceRefreshes handles GET /admin/balance-refreshes
+func (h *BalanceRefreshHandler) GetBalanceRefreshes(c *fiber.Ctx) error {
+	refreshes, err := h.balanceRefreshService.List(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, refreshes)
+}
+
+// GetBalanceRefresh handles GET /admin/balance-refreshes/:id
+func (h *BalanceRefreshHandler) GetBalanceRefresh(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id", "balance refresh")
+	if err != nil {
+		return err
+	}
+
+	refresh, err := h.balanceRefreshService.Get(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, refresh)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BillingHandler struct {
+	billingService *services.BillingService
+}
+
+func NewBillingHandler(billingService *services.BillingService) *BillingHandler {
+	return &BillingHandler{
+		billingService: billingService,
+	}
+}
+
+// GetBilling handles GET /billing, the user's plan tier and subscription
+func (h *BillingHandler) GetBilling(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	status, err := h.billingService.GetStatus(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, status)
+}
+
+// CreateCheckout handles POST /billing/checkout, returning the Stripe checkout page to
+// send the user to for subscribing to the pro tier
+func (h *BillingHandler) CreateCheckout(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	checkout, err := h.billingService.CreateCheckout(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(fiber.StatusCreated), checkout)
+}
+
+// ListInvoices handles GET /billing/invoices, the user's invoices newest first
+func (h *BillingHandler) ListInvoices(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+
+	invoices, err := h.billingService.ListInvoices(c.Context(), userID, limit)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, invoices)
+}
+
+// HandleWebhook handles POST /webhooks/stripe, Stripe's subscription and invoice
+// events, signed in Stripe-Signature. The route is off without a signing secret.
+func (h *BillingHandler) HandleWebhook(c *fiber.Ctx) error {
+	if !h.billingService.WebhookEnabled() {
+		return errors.NotFound("Route")
+	}
+
+	if err := h.billingService.HandleWebhook(c.Context(), c.Body(), c.Get("Stripe-Signature")); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
\ No newline at end of file
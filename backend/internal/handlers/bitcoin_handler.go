@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BitcoinHandler struct {
+	bitcoinService *services.BitcoinService
+}
+
+func NewBitcoinHandler(bitcoinService *services.BitcoinService) *BitcoinHandler {
+	return &BitcoinHandler{
+		bitcoinService: bitcoinService,
+	}
+}
+
+// GetAccounts handles GET /bitcoin/accounts
+func (h *BitcoinHandler) GetAccounts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	accounts, err := h.bitcoinService.GetAccounts(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, accounts)
+}
+
+// AddAccount handles POST /bitcoin/accounts
+func (h *BitcoinHandler) AddAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req models.AddBitcoinAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	account, err := h.bitcoinService.AddAccount(c.Context(), userID, req.Identifier, req.Label)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), account)
+}
+
+// RemoveAccount handles DELETE /bitcoin/accounts/:id
+func (h *BitcoinHandler) RemoveAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	accountID, err := uuidParam(c, "id", "account")
+	if err != nil {
+		return err
+	}
+
+	if err := h.bitcoinService.RemoveAccount(c.Context(), userID, accountID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetPortfolio handles GET /bitcoin/portfolio
+func (h *BitcoinHandler) GetPortfolio(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	portfolio, err := h.bitcoinService.GetPortfolio(c.Context(), userID, providerKeys(c).CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, portfolio)
+}
+
+// GetTransactions handles GET /bitcoin/transactions
+func (h *BitcoinHandler) GetTransactions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	txs, err := h.bitcoinService.GetTransactions(c.Context(), userID, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, txs, fiber.Map{
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetPnL handles GET /bitcoin/pnl
+func (h *BitcoinHandler) GetPnL(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	from := time.Now().AddDate(-1, 0, 0)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return errors.BadRequest("Invalid from date format. Use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return errors.BadRequest("Invalid to date format. Use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	var method pnl.CalculationMethod
+	switch c.Query("method", "fifo") {
+	case "fifo":
+		method = pnl.FIFO
+	case "lifo":
+		method = pnl.LIFO
+	default:
+		return errors.BadRequest("Invalid method. Use 'fifo' or 'lifo'")
+	}
+
+	calculation, err := h.bitcoinService.CalculatePnL(c.Context(), userID, from, to, method, providerKeys(c).CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, calculation)
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/services"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type BridgeHandler struct {
 	bridgeService *services.BridgeService
+	analytics     services.EventTracker
 }
 
 func NewBridgeHandler(bridgeService *services.BridgeService) *BridgeHandler {
@@ -16,6 +19,11 @@ func NewBridgeHandler(bridgeService *services.BridgeService) *BridgeHandler {
 	}
 }
 
+// SetAnalytics reports quotes as quote_requested usage events
+func (h *BridgeHandler) SetAnalytics(analytics services.EventTracker) {
+	h.analytics = analytics
+}
+
 // GetBridgeRoutes handles POST /bridge/routes
 func (h *BridgeHandler) GetBridgeRoutes(c *fiber.Ctx) error {
 	var req services.BridgeRouteRequest
@@ -38,15 +46,27 @@ func (h *BridgeHandler) GetBridgeRoutes(c *fiber.Ctx) error {
 	if req.Slippage == 0 {
 		req.Slippage = 0.5
 	}
+	if req.MaxSlippage < 0 || req.MaxSlippage > 50 {
+		return errors.BadRequest("MaxSlippage must be between 0 and 50 percent")
+	}
 
-	// Get bridge routes
-	routes, err := h.bridgeService.GetRoutes(c.Context(), req)
+	// Get bridge routes, sharing them with the user's identical requests in flight
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	routes, err := h.bridgeService.GetUserRoutes(c.Context(), userID, req)
 	if err != nil {
 		return err
 	}
+	if ok && h.analytics != nil {
+		h.analytics.Track(userID, models.AnalyticsQuoteRequested, map[string]interface{}{
+			"kind":       "bridge",
+			"from_chain": req.FromChain,
+			"to_chain":   req.ToChain,
+			"routes":     len(routes),
+		})
+	}
 
 	// Return routes in expected format
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"routes": routes,
 	})
 }
@@ -70,7 +90,7 @@ func (h *BridgeHandler) ExecuteBridge(c *fiber.Ctx) error {
 	// For now, return mock transaction hash
 	mockTxHash := "0x" + generateMockHash()
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"txHash": mockTxHash,
 	})
 }
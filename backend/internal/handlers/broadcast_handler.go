@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BroadcastHandler struct {
+	broadcastService *services.BroadcastService
+}
+
+func NewBroadcastHandler(broadcastService *services.BroadcastService) *BroadcastHandler {
+	return &BroadcastHandler{
+		broadcastService: broadcastService,
+	}
+}
+
+// SendBroadcast handles POST /admin/broadcasts. With dry_run=true it only previews the
+// audience.
+func (h *BroadcastHandler) SendBroadcast(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateBroadcastRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	dryRun := c.QueryBool("dry_run")
+	result, err := h.broadcastService.Send(c.Context(), adminID, &req, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return respond(c, result)
+	}
+	return respond(c.Status(201), result)
+}
+
+// GetBroadcasts handles GET /admin/broadcasts
+func (h *BroadcastHandler) GetBroadcasts(c *fiber.Ctx) error {
+	broadcasts, err := h.broadcastService.List(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, broadcasts)
+}
+
+// GetBanners handles GET /banners, the active banners shown to the user
+func (h *BroadcastHandler) GetBanners(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	banners, err := h.broadcastService.GetBanners(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, banners)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BudgetHandler struct {
+	budgetService *services.BudgetService
+}
+
+func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+	}
+}
+
+// GetBudgets handles GET /budgets, returning the user's budgets with their spending
+// this month
+func (h *BudgetHandler) GetBudgets(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	budgets, err := h.budgetService.GetBudgets(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, budgets)
+}
+
+// SetBudget handles PUT /budgets/:metric, creating or updating the user's monthly
+// budget for gas_spend, trading_volume or realized_loss
+func (h *BudgetHandler) SetBudget(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.SetBudgetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	budget, err := h.budgetService.SetBudget(c.Context(), userID, c.Params("metric"), &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, budget)
+}
+
+// DeleteBudget handles DELETE /budgets/:metric
+func (h *BudgetHandler) DeleteBudget(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	if err := h.budgetService.DeleteBudget(c.Context(), userID, c.Params("metric")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
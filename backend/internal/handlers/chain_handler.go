@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ChainHandler struct {
+	chainService *services.ChainService
+}
+
+func NewChainHandler(chainService *services.ChainService) *ChainHandler {
+	return &ChainHandler{
+		chainService: chainService,
+	}
+}
+
+// GetCustomChains handles GET /chains/custom
+func (h *ChainHandler) GetCustomChains(c *fiber.Ctx) error {
+	chains, err := h.chainService.ListChains(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, chains, fiber.Map{
+		"total":            len(chains),
+		"userRegistration": h.chainService.UsersCanRegister(c.Context()),
+	})
+}
+
+// RegisterCustomChain handles POST /chains/custom, open to users only while the
+// user_custom_chains feature flag is enabled
+func (h *ChainHandler) RegisterCustomChain(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	if !h.chainService.UsersCanRegister(c.Context()) {
+		return errors.Forbidden("Registering custom chains is not enabled")
+	}
+
+	return h.register(c, &userID)
+}
+
+// AdminRegisterCustomChain handles POST /admin/chains
+func (h *ChainHandler) AdminRegisterCustomChain(c *fiber.Ctx) error {
+	return h.register(c, nil)
+}
+
+func (h *ChainHandler) register(c *fiber.Ctx, createdBy *uuid.UUID) error {
+	var req models.RegisterCustomChainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	chain, err := h.chainService.RegisterChain(c.Context(), createdBy, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), chain)
+}
+
+// DeleteCustomChain handles DELETE /admin/chains/:chainId
+func (h *ChainHandler) DeleteCustomChain(c *fiber.Ctx) error {
+	chainID, err := parseChainID(c.Params("chainId"))
+	if err != nil {
+		return err
+	}
+
+	if err := h.chainService.DeleteChain(c.Context(), chainID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
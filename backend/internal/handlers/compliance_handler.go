@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ComplianceHandler struct {
+	complianceService *services.ComplianceService
+}
+
+func NewComplianceHandler(complianceService *services.ComplianceService) *ComplianceHandler {
+	return &ComplianceHandler{
+		complianceService: complianceService,
+	}
+}
+
+// GetExposure handles GET /compliance/exposure, reporting the exposure of the user's
+// wallets to sanctioned and mixer addresses
+func (h *ComplianceHandler) GetExposure(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	report, err := h.complianceService.GetReport(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, report)
+}
+
+// GetFlaggedExposures handles GET /admin/compliance/exposure, listing the wallets across
+// users exposed at ?level= (default medium) or above
+func (h *ComplianceHandler) GetFlaggedExposures(c *fiber.Ctx) error {
+	report, err := h.complianceService.GetFlagged(c.Context(), c.Query("level"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, report)
+}
+
+// GetScreeningLists handles GET /admin/compliance/lists
+func (h *ComplianceHandler) GetScreeningLists(c *fiber.Ctx) error {
+	lists, err := h.complianceService.GetLists(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, lists)
+}
+
+// CreateScreeningList handles POST /admin/compliance/lists
+func (h *ComplianceHandler) CreateScreeningList(c *fiber.Ctx) error {
+	var req models.CreateScreeningListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	list, err := h.complianceService.CreateList(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), list)
+}
+
+// SetScreeningListAddresses handles PUT /admin/compliance/lists/:id/addresses, replacing
+// the list's addresses
+func (h *ComplianceHandler) SetScreeningListAddresses(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id", "screening list")
+	if err != nil {
+		return err
+	}
+	var req models.SetScreeningListAddressesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	if err := h.complianceService.SetListAddresses(c.Context(), id, &req); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// DeleteScreeningList handles DELETE /admin/compliance/lists/:id
+func (h *ComplianceHandler) DeleteScreeningList(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id", "screening list")
+	if err != nil {
+		return err
+	}
+
+	if err := h.complianceService.DeleteList(c.Context(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CustomMetricHandler struct {
+	customMetricService *services.CustomMetricService
+}
+
+func NewCustomMetricHandler(customMetricService *services.CustomMetricService) *CustomMetricHandler {
+	return &CustomMetricHandler{
+		customMetricService: customMetricService,
+	}
+}
+
+// GetCustomMetrics handles GET /metrics
+func (h *CustomMetricHandler) GetCustomMetrics(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	metrics, err := h.customMetricService.List(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, metrics, fiber.Map{
+		"total": len(metrics),
+	})
+}
+
+// GetMetricVariables handles GET /metrics/variables, what expressions can use
+func (h *CustomMetricHandler) GetMetricVariables(c *fiber.Ctx) error {
+	return respond(c, h.customMetricService.Variables())
+}
+
+// CreateCustomMetric handles POST /metrics
+func (h *CustomMetricHandler) CreateCustomMetric(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateCustomMetricRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	metric, err := h.customMetricService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), metric)
+}
+
+// GetCustomMetric handles GET /metrics/:id
+func (h *CustomMetricHandler) GetCustomMetric(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "custom metric")
+	if err != nil {
+		return err
+	}
+
+	metric, err := h.customMetricService.Get(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, metric)
+}
+
+// UpdateCustomMetric handles PUT /metrics/:id
+func (h *CustomMetricHandler) UpdateCustomMetric(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "custom metric")
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateCustomMetricRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	metric, err := h.customMetricService.Update(c.Context(), userID, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, metric)
+}
+
+// DeleteCustomMetric handles DELETE /metrics/:id
+func (h *CustomMetricHandler) DeleteCustomMetric(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "custom metric")
+	if err != nil {
+		return err
+	}
+
+	if err := h.customMetricService.Delete(c.Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetWidgets handles GET /widgets, the user's metrics computed for their dashboard
+// tiles. refresh=true computes them all again instead of showing cached values.
+func (h *CustomMetricHandler) GetWidgets(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	widgets, err := h.customMetricService.Widgets(c.Context(), userID, c.QueryBool("refresh"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, widgets)
+}
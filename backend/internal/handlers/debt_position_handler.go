@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DebtPositionHandler struct {
+	debtPositionService *services.DebtPositionService
+}
+
+func NewDebtPositionHandler(debtPositionService *services.DebtPositionService) *DebtPositionHandler {
+	return &DebtPositionHandler{
+		debtPositionService: debtPositionService,
+	}
+}
+
+// GetDebtPositions handles GET /positions/debt
+func (h *DebtPositionHandler) GetDebtPositions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	positions, err := h.debtPositionService.GetPositions(c.Context(), userID, c.Query("address"), providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, positions)
+}
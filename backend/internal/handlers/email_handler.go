@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/email"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type EmailHandler struct {
+	emailService  *services.EmailService
+	webhookSecret string
+}
+
+// NewEmailHandler serves the provider webhooks, authenticated by webhookSecret, and the
+// admin suppression list. The webhooks are off when webhookSecret is empty.
+func NewEmailHandler(emailService *services.EmailService, webhookSecret string) *EmailHandler {
+	return &EmailHandler{
+		emailService:  emailService,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// HandleWebhook handles POST /webhooks/email/:provider, the bounce and complaint
+// notifications of SES (through SNS) or SendGrid's event webhook
+func (h *EmailHandler) HandleWebhook(c *fiber.Ctx) error {
+	if h.webhookSecret == "" {
+		return errors.NotFound("Route")
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.webhookSecret)) != 1 {
+		return errors.Unauthorized("Invalid webhook token")
+	}
+
+	provider := c.Params("provider")
+	var events []email.Event
+	switch provider {
+	case email.ProviderSES:
+		parsed, subscribeURL, err := email.ParseSESNotification(c.Body())
+		if err != nil {
+			return errors.BadRequest(err.Error())
+		}
+		if subscribeURL != "" {
+			if err := h.emailService.ConfirmSNSSubscription(c.Context(), subscribeURL); err != nil {
+				return err
+			}
+		}
+		events = parsed
+	case email.ProviderSendGrid:
+		parsed, err := email.ParseSendGridEvents(c.Body())
+		if err != nil {
+			return errors.BadRequest(err.Error())
+		}
+		events = parsed
+	default:
+		return errors.NotFound("Email provider")
+	}
+
+	if err := h.emailService.HandleEvents(c.Context(), provider, events); err != nil {
+		return err
+	}
+	return c.SendStatus(204)
+}
+
+// GetSuppressions handles GET /admin/email/suppressions
+func (h *EmailHandler) GetSuppressions(c *fiber.Ctx) error {
+	suppressions, err := h.emailService.ListSuppressions(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, suppressions)
+}
+
+// CreateSuppression handles POST /admin/email/suppressions
+func (h *EmailHandler) CreateSuppression(c *fiber.Ctx) error {
+	var req models.CreateEmailSuppressionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	suppression, err := h.emailService.AddSuppression(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), suppression)
+}
+
+// DeleteSuppression handles DELETE /admin/email/suppressions/:email
+func (h *EmailHandler) DeleteSuppression(c *fiber.Ctx) error {
+	if err := h.emailService.RemoveSuppression(c.Context(), c.Params("email")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetDeliveries handles GET /admin/email/deliveries?email=, the latest emails to an address
+func (h *EmailHandler) GetDeliveries(c *fiber.Ctx) error {
+	deliveries, err := h.emailService.GetDeliveries(c.Context(), c.Query("email"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, deliveries)
+}
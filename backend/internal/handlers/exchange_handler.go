@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ExchangeHandler struct {
+	exchangeService *services.ExchangeService
+}
+
+func NewExchangeHandler(exchangeService *services.ExchangeService) *ExchangeHandler {
+	return &ExchangeHandler{
+		exchangeService: exchangeService,
+	}
+}
+
+// GetAccounts handles GET /exchanges/accounts
+func (h *ExchangeHandler) GetAccounts(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	accounts, err := h.exchangeService.GetAccounts(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, accounts)
+}
+
+// AddAccount handles POST /exchanges/accounts
+func (h *ExchangeHandler) AddAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req models.AddExchangeAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	account, err := h.exchangeService.AddAccount(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), account)
+}
+
+// RemoveAccount handles DELETE /exchanges/accounts/:id
+func (h *ExchangeHandler) RemoveAccount(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	accountID, err := uuidParam(c, "id", "account")
+	if err != nil {
+		return err
+	}
+
+	if err := h.exchangeService.RemoveAccount(c.Context(), userID, accountID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetPortfolio handles GET /exchanges/portfolio
+func (h *ExchangeHandler) GetPortfolio(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	portfolio, err := h.exchangeService.GetPortfolio(c.Context(), userID, providerKeys(c).CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, portfolio)
+}
+
+// GetTrades handles GET /exchanges/trades
+func (h *ExchangeHandler) GetTrades(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	trades, err := h.exchangeService.GetTrades(c.Context(), userID, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, trades, fiber.Map{
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetPnL handles GET /exchanges/pnl/:asset
+func (h *ExchangeHandler) GetPnL(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	from := time.Now().AddDate(-1, 0, 0)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return errors.BadRequest("Invalid from date format. Use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return errors.BadRequest("Invalid to date format. Use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	var method pnl.CalculationMethod
+	switch c.Query("method", "fifo") {
+	case "fifo":
+		method = pnl.FIFO
+	case "lifo":
+		method = pnl.LIFO
+	default:
+		return errors.BadRequest("Invalid method. Use 'fifo' or 'lifo'")
+	}
+
+	calculation, err := h.exchangeService.CalculatePnL(c.Context(), userID, c.Params("asset"), from, to, method, providerKeys(c).CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, calculation)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ExperimentHandler struct {
+	experimentService *services.ExperimentService
+}
+
+func NewExperimentHandler(experimentService *services.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+	}
+}
+
+// GetVariant handles GET /experiments/:name, the user's variant of an experiment. Asking
+// counts as the user being exposed to it.
+func (h *ExperimentHandler) GetVariant(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	assignment, err := h.experimentService.Variant(c.Context(), userID, c.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, assignment)
+}
+
+// GetResults handles GET /admin/experiments/:name/results, each variant's exposures and
+// conversion rates
+func (h *ExperimentHandler) GetResults(c *fiber.Ctx) error {
+	results, err := h.experimentService.Results(c.Context(), c.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, results)
+}
\ No newline at end of file
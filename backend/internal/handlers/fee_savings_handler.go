@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FeeSavingsHandler struct {
+	advisor *services.FeeSavingsAdvisor
+}
+
+func NewFeeSavingsHandler(advisor *services.FeeSavingsAdvisor) *FeeSavingsHandler {
+	return &FeeSavingsHandler{
+		advisor: advisor,
+	}
+}
+
+// GetFeeSavings handles GET /analytics/fee-savings/:address. It compares the gas the
+// wallet paid on mainnet over the last days with what the same activity costs on L2s,
+// suggesting where to move it with a route bridging amount wei of ETH there.
+func (h *FeeSavingsHandler) GetFeeSavings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	days, err := strconv.Atoi(c.Query("days", "0"))
+	if err != nil {
+		return errors.BadRequest("Invalid days parameter")
+	}
+
+	report, err := h.advisor.Suggest(c.Context(), userID, address, days, c.Query("amount"), providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, report)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FeedHandler struct {
+	feedService *services.FeedService
+}
+
+func NewFeedHandler(feedService *services.FeedService) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+	}
+}
+
+// GetFeed handles GET /feed. holdings=true narrows it to posts about the user's
+// positions and tokens; before, an RFC 3339 time, pages back past the oldest item shown.
+func (h *FeedHandler) GetFeed(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		return errors.BadRequest("Limit must be between 1 and 100")
+	}
+
+	var before *time.Time
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		t, err := time.Parse(time.RFC3339, beforeStr)
+		if err != nil {
+			return errors.BadRequest("Invalid before time. Use RFC 3339")
+		}
+		before = &t
+	}
+
+	items, err := h.feedService.GetFeed(c.Context(), userID, c.Query("holdings") == "true", before, limit)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, items)
+}
+
+// GetFeedSources handles GET /admin/feed-sources
+func (h *FeedHandler) GetFeedSources(c *fiber.Ctx) error {
+	sources, err := h.feedService.ListSources(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, sources)
+}
+
+// CreateFeedSource handles POST /admin/feed-sources
+func (h *FeedHandler) CreateFeedSource(c *fiber.Ctx) error {
+	var req models.CreateFeedSourceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	source, err := h.feedService.CreateSource(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), source)
+}
+
+// DeleteFeedSource handles DELETE /admin/feed-sources/:id
+func (h *FeedHandler) DeleteFeedSource(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id", "feed source")
+	if err != nil {
+		return err
+	}
+
+	if err := h.feedService.DeleteSource(c.Context(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ImpersonationHandler struct {
+	impersonationService *services.ImpersonationService
+}
+
+func NewImpersonationHandler(impersonationService *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// StartImpersonation handles POST /admin/impersonations. The returned token, sent in the
+// X-Impersonation-Token header, shows the user's data read-only until it expires or the
+// session is ended.
+func (h *ImpersonationHandler) StartImpersonation(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.StartImpersonationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	grant, err := h.impersonationService.Start(c.Context(), adminID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), grant)
+}
+
+// EndImpersonation handles DELETE /admin/impersonations/:id
+func (h *ImpersonationHandler) EndImpersonation(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "impersonation session")
+	if err != nil {
+		return err
+	}
+
+	if err := h.impersonationService.End(c.Context(), adminID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetAuditLog handles GET /admin/audit-log?actor=&user=&session=&before=&limit=, newest
+// entries first
+func (h *ImpersonationHandler) GetAuditLog(c *fiber.Ctx) error {
+	var filter repos.AuditLogFilter
+	var err error
+	if filter.ActorID, err = queryUUID(c, "actor"); err != nil {
+		return err
+	}
+	if filter.TargetUserID, err = queryUUID(c, "user"); err != nil {
+		return err
+	}
+	if filter.SessionID, err = queryUUID(c, "session"); err != nil {
+		return err
+	}
+	if before := c.Query("before"); before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return errors.BadRequest("Invalid before time. Use RFC3339")
+		}
+		filter.Before = &t
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return errors.BadRequest("Invalid limit")
+		}
+		filter.Limit = n
+	}
+
+	entries, err := h.impersonationService.ListAuditLog(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, entries)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobGraphHandler struct {
+	jobGraphService *services.JobGraphService
+}
+
+func NewJobGraphHandler(jobGraphService *services.JobGraphService) *JobGraphHandler {
+	return &JobGraphHandler{
+		jobGraphService: jobGraphService,
+	}
+}
+
+// GetJobGraph handles GET /admin/jobs, the worker's scheduled jobs in the order they run
+// when due together, with what each depends on and its latest run
+func (h *JobGraphHandler) GetJobGraph(c *fiber.Ctx) error {
+	graph, err := h.jobGraphService.GetGraph(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, graph)
+}
\ No newline at end of file
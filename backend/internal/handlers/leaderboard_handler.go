@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService *services.LeaderboardService
+}
+
+func NewLeaderboardHandler(leaderboardService *services.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		leaderboardService: leaderboardService,
+	}
+}
+
+// GetLeaderboard handles GET /leaderboards/:board
+func (h *LeaderboardHandler) GetLeaderboard(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "25"))
+	if err != nil || limit <= 0 || limit > 100 {
+		return errors.BadRequest("Limit must be between 1 and 100")
+	}
+
+	board, err := h.leaderboardService.GetLeaderboard(c.Context(), c.Params("board"), limit)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, board)
+}
+
+// GetParticipation handles GET /leaderboards/participation. The data is null for users
+// who haven't opted in.
+func (h *LeaderboardHandler) GetParticipation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	participant, err := h.leaderboardService.GetParticipation(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, participant)
+}
+
+// UpdateParticipation handles PUT /leaderboards/participation
+func (h *LeaderboardHandler) UpdateParticipation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.LeaderboardParticipationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	participant, err := h.leaderboardService.SetParticipation(c.Context(), userID, req.OptIn)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, participant)
+}
+
+// GetPlatformStats handles GET /stats/platform
+func (h *LeaderboardHandler) GetPlatformStats(c *fiber.Ctx) error {
+	stats, err := h.leaderboardService.GetPlatformStats(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, stats)
+}
+
+// GetParticipants handles GET /admin/leaderboards/participants
+func (h *LeaderboardHandler) GetParticipants(c *fiber.Ctx) error {
+	participants, err := h.leaderboardService.ListParticipants(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, participants)
+}
+
+// UpdateParticipantVisibility handles PUT /admin/leaderboards/participants/:userId
+func (h *LeaderboardHandler) UpdateParticipantVisibility(c *fiber.Ctx) error {
+	userID, err := uuidParam(c, "userId", "user")
+	if err != nil {
+		return err
+	}
+
+	var req models.LeaderboardParticipantVisibilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	if err := h.leaderboardService.SetParticipantHidden(c.Context(), userID, req.Hidden); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
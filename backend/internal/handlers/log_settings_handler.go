@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LogSettingsHandler struct {
+	logSettingsService *services.LogSettingsService
+}
+
+func NewLogSettingsHandler(logSettingsService *services.LogSettingsService) *LogSettingsHandler {
+	return &LogSettingsHandler{
+		logSettingsService: logSettingsService,
+	}
+}
+
+// GetLogSettings handles GET /admin/log-settings
+func (h *LogSettingsHandler) GetLogSettings(c *fiber.Ctx) error {
+	settings, err := h.logSettingsService.Get(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, settings)
+}
+
+// UpdateLogSettings handles PUT /admin/log-settings. The settings apply to every API
+// and worker instance until they expire, within the reload interval.
+func (h *LogSettingsHandler) UpdateLogSettings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.UpdateLogSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	settings, err := h.logSettingsService.Update(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, settings)
+}
+
+// ResetLogSettings handles DELETE /admin/log-settings, going back to LOG_LEVEL
+func (h *LogSettingsHandler) ResetLogSettings(c *fiber.Ctx) error {
+	settings, err := h.logSettingsService.Reset(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, settings)
+}
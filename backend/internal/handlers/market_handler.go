@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MarketHandler serves the market overview. Its routes need no sign-in.
+type MarketHandler struct {
+	marketService *services.MarketService
+}
+
+func NewMarketHandler(marketService *services.MarketService) *MarketHandler {
+	return &MarketHandler{
+		marketService: marketService,
+	}
+}
+
+// GetOverview handles GET /market/overview
+func (h *MarketHandler) GetOverview(c *fiber.Ctx) error {
+	overview, err := h.marketService.GetOverview(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, overview)
+}
+
+// GetTrending handles GET /market/trending
+func (h *MarketHandler) GetTrending(c *fiber.Ctx) error {
+	overview, err := h.marketService.GetOverview(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, overview.Trending)
+}
+
+// GetMovers handles GET /market/movers, the top gainers and losers among tokens held on
+// the platform
+func (h *MarketHandler) GetMovers(c *fiber.Ctx) error {
+	overview, err := h.marketService.GetOverview(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, fiber.Map{
+		"gainers": overview.Gainers,
+		"losers":  overview.Losers,
+	})
+}
+
+// GetAPYChanges handles GET /market/apy-changes
+func (h *MarketHandler) GetAPYChanges(c *fiber.Ctx) error {
+	overview, err := h.marketService.GetOverview(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, overview.APYChanges)
+}
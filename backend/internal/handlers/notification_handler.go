@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+	settingsService services.NotificationSettingsService
+}
+
+func NewNotificationHandler(settingsService services.NotificationSettingsService) *NotificationHandler {
+	return &NotificationHandler{
+		settingsService: settingsService,
+	}
+}
+
+// GetSettings handles GET /notifications/settings
+func (h *NotificationHandler) GetSettings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	settings, err := h.settingsService.GetSettings(c.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to get notification settings",
+			"error", err.Error(),
+			"userID", userID,
+		)
+		return errors.Internal("Failed to get notification settings")
+	}
+
+	return respondLegacy(c, settings)
+}
+
+// UpdateSettings handles PUT /notifications/settings
+func (h *NotificationHandler) UpdateSettings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.UpdateNotificationSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	settings, err := h.settingsService.UpdateSettings(c.Context(), userID, &req)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return appErr
+		}
+		logger.Error("Failed to update notification settings",
+			"error", err.Error(),
+			"userID", userID,
+		)
+		return errors.Internal("Failed to update notification settings")
+	}
+
+	return respondLegacy(c, settings)
+}
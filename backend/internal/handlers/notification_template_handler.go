@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type NotificationTemplateHandler struct {
+	templateService *services.NotificationTemplateService
+}
+
+func NewNotificationTemplateHandler(templateService *services.NotificationTemplateService) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// GetTemplates handles GET /alerts/templates, listing the ready-made message templates
+// and the variables templates can use
+func (h *NotificationTemplateHandler) GetTemplates(c *fiber.Ctx) error {
+	return respond(c, fiber.Map{
+		"templates": services.NotificationTemplateLibrary,
+		"variables": services.NotificationTemplateVariables,
+	})
+}
+
+// PreviewTemplate handles POST /alerts/templates/preview
+func (h *NotificationTemplateHandler) PreviewTemplate(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.PreviewNotificationTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	preview, err := h.templateService.Preview(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, preview)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type OnboardingHandler struct {
+	onboardingService *services.OnboardingService
+}
+
+func NewOnboardingHandler(onboardingService *services.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingService: onboardingService,
+	}
+}
+
+// GetProgress handles GET /onboarding, the user's progress through the guided setup
+func (h *OnboardingHandler) GetProgress(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	progress, err := h.onboardingService.GetProgress(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, progress)
+}
\ No newline at end of file
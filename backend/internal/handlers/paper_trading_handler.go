@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaperTradingHandler struct {
+	paperTradingService *services.PaperTradingService
+}
+
+func NewPaperTradingHandler(paperTradingService *services.PaperTradingService) *PaperTradingHandler {
+	return &PaperTradingHandler{
+		paperTradingService: paperTradingService,
+	}
+}
+
+// GetPortfolios handles GET /paper/portfolios
+func (h *PaperTradingHandler) GetPortfolios(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	portfolios, err := h.paperTradingService.List(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, portfolios)
+}
+
+// CreatePortfolio handles POST /paper/portfolios
+func (h *PaperTradingHandler) CreatePortfolio(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreatePaperPortfolioRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	portfolio, err := h.paperTradingService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), portfolio)
+}
+
+// GetPortfolio handles GET /paper/portfolios/:id, valuing the portfolio at live prices
+func (h *PaperTradingHandler) GetPortfolio(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "paper portfolio")
+	if err != nil {
+		return err
+	}
+
+	summary, err := h.paperTradingService.GetSummary(c.Context(), userID, id, providerKeys(c).CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, summary)
+}
+
+// DeletePortfolio handles DELETE /paper/portfolios/:id
+func (h *PaperTradingHandler) DeletePortfolio(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "paper portfolio")
+	if err != nil {
+		return err
+	}
+
+	if err := h.paperTradingService.Delete(c.Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetTrades handles GET /paper/portfolios/:id/trades
+func (h *PaperTradingHandler) GetTrades(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "paper portfolio")
+	if err != nil {
+		return err
+	}
+
+	trades, err := h.paperTradingService.GetTrades(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, trades)
+}
+
+// Trade handles POST /paper/portfolios/:id/trades, executing at the live price
+func (h *PaperTradingHandler) Trade(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "paper portfolio")
+	if err != nil {
+		return err
+	}
+
+	var req models.PaperTradeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	trade, portfolio, err := h.paperTradingService.Trade(c.Context(), userID, id, &req, providerKeys(c).CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), fiber.Map{
+		"trade":     trade,
+		"portfolio": portfolio,
+	})
+}
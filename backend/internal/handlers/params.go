@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// uuidParam parses the route parameter name as a UUID, naming what it identifies in the
+// error, e.g. "Invalid wallet ID"
+func uuidParam(c *fiber.Ctx, name, label string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		return uuid.Nil, errors.BadRequest("Invalid " + label + " ID")
+	}
+	return id, nil
+}
+
+// parseChainID parses a chain ID taken from a request. It only checks the format, for
+// endpoints that serve chains the platform doesn't read, such as yield pool listings.
+func parseChainID(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, errors.BadRequest("Chain ID is required")
+	}
+	chainID, err := strconv.Atoi(raw)
+	if err != nil || chainID <= 0 {
+		return 0, errors.BadRequest("Invalid chain ID")
+	}
+	return chainID, nil
+}
+
+// supportedChainID is parseChainID for endpoints that read from the chain, which must
+// be built in or registered as a custom chain
+func supportedChainID(raw string) (int, error) {
+	chainID, err := parseChainID(raw)
+	if err != nil {
+		return 0, err
+	}
+	if !blockchain.IsSupportedChain(chainID) {
+		return 0, errors.BadRequest("Unsupported chain ID " + strconv.Itoa(chainID))
+	}
+	return chainID, nil
+}
+
+// optionalChainIDQuery reads the chainId query parameter, which must be a supported
+// chain when set
+func optionalChainIDQuery(c *fiber.Ctx) (*int, error) {
+	raw := c.Query("chainId")
+	if raw == "" {
+		return nil, nil
+	}
+	chainID, err := supportedChainID(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &chainID, nil
+}
+
+// queryUUID parses an optional UUID query parameter
+func queryUUID(c *fiber.Ctx, param string) (*uuid.UUID, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, errors.BadRequest("Invalid " + param + " ID")
+	}
+	return &id, nil
+}
+
+// parseWindow parses a look-back window given in whole hours or days, e.g. 24h or 7d
+func parseWindow(raw string) (time.Duration, error) {
+	unit := time.Hour
+	switch {
+	case strings.HasSuffix(raw, "h"):
+	case strings.HasSuffix(raw, "d"):
+		unit = 24 * time.Hour
+	default:
+		return 0, errors.BadRequest("Invalid window " + raw + ", e.g. 24h or 7d")
+	}
+	count, err := strconv.Atoi(raw[:len(raw)-1])
+	if err != nil || count <= 0 {
+		return 0, errors.BadRequest("Invalid window " + raw + ", e.g. 24h or 7d")
+	}
+	return time.Duration(count) * unit, nil
+}
\ No newline at end of file
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainIDParams(t *testing.T) {
+	for raw, valid := range map[string]bool{"1": true, " 137 ": true, "": false, "-1": false, "0": false, "0x1": false, "1; DROP TABLE": false} {
+		_, err := parseChainID(raw)
+		assert.Equal(t, valid, err == nil, "parseChainID(%q)", raw)
+	}
+
+	_, err := supportedChainID("1")
+	assert.NoError(t, err)
+	_, err = supportedChainID("999999999")
+	assert.Contains(t, err.Error(), "Unsupported chain ID 999999999")
+}
+
+func TestParseWindow(t *testing.T) {
+	for raw, want := range map[string]time.Duration{"24h": 24 * time.Hour, "1h": time.Hour, "7d": 7 * 24 * time.Hour} {
+		window, err := parseWindow(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, window, raw)
+	}
+	for _, raw := range []string{"", "h", "0d", "-1h", "1w", "1.5h", "30m"} {
+		_, err := parseWindow(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestParamHelpers(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.Status(400).SendString(err.(*errors.AppError).Message)
+	}})
+	app.Get("/wallets/:id", func(c *fiber.Ctx) error {
+		if _, err := uuidParam(c, "id", "wallet"); err != nil {
+			return err
+		}
+		chainID, err := optionalChainIDQuery(c)
+		if err != nil {
+			return err
+		}
+		if chainID != nil {
+			return c.SendString("chain")
+		}
+		return c.SendString("ok")
+	})
+
+	for path, want := range map[string]string{
+		"/wallets/5f8c4a52-6b1e-4c7a-9d3e-2a1b0c9d8e7f":             "ok",
+		"/wallets/5f8c4a52-6b1e-4c7a-9d3e-2a1b0c9d8e7f?chainId=10":  "chain",
+		"/wallets/5f8c4a52-6b1e-4c7a-9d3e-2a1b0c9d8e7f?chainId=abc": "Invalid chain ID",
+		"/wallets/not-a-uuid": "Invalid wallet ID",
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		assert.Equal(t, want, string(body[:n]), path)
+	}
+}
+
+func TestBalancePin(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.Status(400).SendString(err.(*errors.AppError).Message)
+	}})
+	app.Get("/balances", func(c *fiber.Ctx) error {
+		pin, err := balancePin(c)
+		if err != nil {
+			return err
+		}
+		switch {
+		case pin == nil:
+			return c.SendString("latest")
+		case pin.Block == nil:
+			return c.SendString("head")
+		}
+		return c.SendString(strconv.FormatInt(*pin.Block, 10))
+	})
+
+	for path, want := range map[string]string{
+		"/balances":                    "latest",
+		"/balances?consistency=latest": "latest",
+		"/balances?consistency=pinned": "head",
+		"/balances?block=19000000":     "19000000",
+		"/balances?consistency=exact":  "Invalid consistency, must be latest or pinned",
+		"/balances?block=-1":           "Invalid block",
+		"/balances?block=latest":       "Invalid block",
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		assert.Equal(t, want, string(body[:n]), path)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PortfolioDiffHandler struct {
+	diffService *services.PortfolioDiffService
+}
+
+func NewPortfolioDiffHandler(diffService *services.PortfolioDiffService) *PortfolioDiffHandler {
+	return &PortfolioDiffHandler{
+		diffService: diffService,
+	}
+}
+
+// GetDiff handles GET /portfolio/:address/diff, what changed in one of the user's
+// wallets over the since window (24h by default)
+func (h *PortfolioDiffHandler) GetDiff(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	chainID, err := optionalChainIDQuery(c)
+	if err != nil {
+		return err
+	}
+
+	window, err := parseWindow(c.Query("since", "24h"))
+	if err != nil {
+		return err
+	}
+
+	diff, err := h.diffService.Diff(c.Context(), userID, address, chainID, window)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, diff)
+}
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,61 +19,127 @@ func NewPortfolioHandler(portfolioService *services.PortfolioService) *Portfolio
 	}
 }
 
-// GetBalances handles GET /portfolio/:address/balances
+// GetBalances handles GET /portfolio/:address/balances. consistency=pinned reads every
+// balance at the chain head, and block at that block instead.
 func (h *PortfolioHandler) GetBalances(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse query parameters
-	var chainID *int
-	if chainParam := c.Query("chainId"); chainParam != "" {
-		chain, err := strconv.Atoi(chainParam)
-		if err != nil {
-			return errors.BadRequest("Invalid chainId")
-		}
-		chainID = &chain
+	chainID, err := optionalChainIDQuery(c)
+	if err != nil {
+		return err
 	}
 
 	hideSmall := c.Query("hideSmall") == "true"
+	pin, err := balancePin(c)
+	if err != nil {
+		return err
+	}
 
-	// Extract API keys from request headers
-	alchemyAPIKey := c.Get("X-Alchemy-API-Key", "")
-	coinGeckoAPIKey := c.Get("X-CoinGecko-API-Key", "")
+	// Resolve provider API keys (request headers, then the user's stored keys)
+	keys := providerKeys(c)
+	alchemyAPIKey, coinGeckoAPIKey := keys.Alchemy, keys.CoinGecko
 
 	// Get balances
-	balances, err := h.portfolioService.GetBalances(c.Context(), address, chainID, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+	balances, err := h.portfolioService.GetBalances(c.Context(), address, chainID, hideSmall, pin, alchemyAPIKey, coinGeckoAPIKey)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(balances)
+	return respondLegacy(c, balances)
+}
+
+// GetMultiChainBalances handles GET /portfolio/:address/chains, leaving testnets out
+// unless include_testnets is set. consistency=pinned reads each chain's balances at its
+// head; the chains' heights differ, so a block can't be given.
+func (h *PortfolioHandler) GetMultiChainBalances(c *fiber.Ctx) error {
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	hideSmall := c.Query("hideSmall") == "true"
+	includeTestnets, err := includeTestnets(c)
+	if err != nil {
+		return err
+	}
+
+	pin, err := balancePin(c)
+	if err != nil {
+		return err
+	}
+	if pin != nil && pin.Block != nil {
+		return errors.BadRequest("block can only be given for a single chain")
+	}
+
+	keys := providerKeys(c)
+
+	portfolio, err := h.portfolioService.GetMultiChainBalances(c.Context(), address, hideSmall, includeTestnets, pin != nil, keys.Alchemy, keys.CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c, portfolio)
+}
+
+// balancePin reads the consistency and block query parameters. Balances are pinned when
+// consistency is pinned or a block is given; by default they're read at the latest block.
+func balancePin(c *fiber.Ctx) (*services.BalancePin, error) {
+	var pin *services.BalancePin
+	switch c.Query("consistency", "latest") {
+	case "latest":
+	case "pinned":
+		pin = &services.BalancePin{}
+	default:
+		return nil, errors.BadRequest("Invalid consistency, must be latest or pinned")
+	}
+
+	if param := c.Query("block"); param != "" {
+		block, err := strconv.ParseInt(param, 10, 64)
+		if err != nil || block < 0 {
+			return nil, errors.BadRequest("Invalid block")
+		}
+		pin = &services.BalancePin{Block: &block}
+	}
+	return pin, nil
+}
+
+// includeTestnets reads the include_testnets query parameter, defaulting to the
+// environment's testnet mode
+func includeTestnets(c *fiber.Ctx) (bool, error) {
+	param := c.Query("include_testnets")
+	if param == "" {
+		return blockchain.TestnetMode(), nil
+	}
+	include, err := strconv.ParseBool(param)
+	if err != nil {
+		return false, errors.BadRequest("Invalid include_testnets")
+	}
+	return include, nil
 }
 
 // GetHistory handles GET /portfolio/:address/history
 func (h *PortfolioHandler) GetHistory(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse query parameters
-	var chainID *int
-	if chainParam := c.Query("chainId"); chainParam != "" {
-		chain, err := strconv.Atoi(chainParam)
-		if err != nil {
-			return errors.BadRequest("Invalid chainId")
-		}
-		chainID = &chain
+	chainID, err := optionalChainIDQuery(c)
+	if err != nil {
+		return err
 	}
 
 	period := c.Query("period", "1w")
 	interval := c.Query("interval", "1d")
 
-	// Extract API keys from request headers
-	alchemyAPIKey := c.Get("X-Alchemy-API-Key", "")
-	coinGeckoAPIKey := c.Get("X-CoinGecko-API-Key", "")
+	// Resolve provider API keys (request headers, then the user's stored keys)
+	keys := providerKeys(c)
+	alchemyAPIKey, coinGeckoAPIKey := keys.Alchemy, keys.CoinGecko
 
 	// Validate period
 	validPeriods := map[string]bool{
@@ -97,7 +164,32 @@ func (h *PortfolioHandler) GetHistory(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"history": history,
 	})
+}
+
+// GetSectorAllocation handles GET /portfolio/:address/sectors
+func (h *PortfolioHandler) GetSectorAllocation(c *fiber.Ctx) error {
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	chainID, err := optionalChainIDQuery(c)
+	if err != nil {
+		return err
+	}
+
+	// Resolve provider API keys (request headers, then the user's stored keys)
+	keys := providerKeys(c)
+
+	sectors, err := h.portfolioService.GetSectorAllocation(c.Context(), address, chainID, keys.Alchemy, keys.CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c, fiber.Map{
+		"sectors": sectors,
+	})
 }
\ No newline at end of file
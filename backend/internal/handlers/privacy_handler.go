@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PrivacyHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewPrivacyHandler(analyticsService *services.AnalyticsService) *PrivacyHandler {
+	return &PrivacyHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetAnalytics handles GET /privacy/analytics, whether the user opted out of usage
+// analytics
+func (h *PrivacyHandler) GetAnalytics(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	settings, err := h.analyticsService.GetSettings(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, settings)
+}
+
+// UpdateAnalytics handles PUT /privacy/analytics
+func (h *PrivacyHandler) UpdateAnalytics(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.UpdateAnalyticsSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	settings, err := h.analyticsService.SetOptOut(c.Context(), userID, req.OptedOut)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, settings)
+}
\ No newline at end of file
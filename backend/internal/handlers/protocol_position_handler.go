@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ProtocolPositionHandler struct {
+	protocolPositionService *services.ProtocolPositionService
+}
+
+func NewProtocolPositionHandler(protocolPositionService *services.ProtocolPositionService) *ProtocolPositionHandler {
+	return &ProtocolPositionHandler{
+		protocolPositionService: protocolPositionService,
+	}
+}
+
+// GetAdapters handles GET /positions/protocols/adapters
+func (h *ProtocolPositionHandler) GetAdapters(c *fiber.Ctx) error {
+	return respond(c, h.protocolPositionService.GetAdapters())
+}
+
+// GetPositions handles GET /positions/protocols, optionally narrowed by address and protocol
+func (h *ProtocolPositionHandler) GetPositions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	positions, err := h.protocolPositionService.GetPositions(c.Context(), userID, c.Query("address"), c.Query("protocol"), providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, positions)
+}
+
+// BuildTransactions handles POST /positions/protocols/:protocol/transactions
+func (h *ProtocolPositionHandler) BuildTransactions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req services.ProtocolTxsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	txs, err := h.protocolPositionService.BuildTransactions(c.Context(), userID, c.Params("protocol"), req, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, txs)
+}
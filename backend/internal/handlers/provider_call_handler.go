@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProviderCallHandler struct {
+	callLog *services.ProviderCallLog
+}
+
+func NewProviderCallHandler(callLog *services.ProviderCallLog) *ProviderCallHandler {
+	return &ProviderCallHandler{
+		callLog: callLog,
+	}
+}
+
+// GetProviderCalls handles GET /admin/provider-calls. Calls are looked up by the API
+// request ID that made them (requestId), by provider, or by time (from and to, RFC 3339).
+func (h *ProviderCallHandler) GetProviderCalls(c *fiber.Ctx) error {
+	filter := models.ProviderCallFilter{
+		RequestID: c.Query("requestId"),
+		Provider:  c.Query("provider"),
+	}
+	for name, dest := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return errors.BadRequest("Invalid " + name + " time; expected RFC 3339")
+		}
+		*dest = &t
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return errors.BadRequest("Limit must be a positive number")
+		}
+		filter.Limit = limit
+	}
+
+	calls, err := h.callLog.List(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, calls, fiber.Map{
+		"total": len(calls),
+	})
+}
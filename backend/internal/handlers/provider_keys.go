@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// providerKeys returns the provider keys resolved by middleware.ProviderKeys,
+// falling back to the raw request headers when the middleware isn't mounted
+func providerKeys(c *fiber.Ctx) models.ProviderKeys {
+	if keys, ok := c.Locals("providerKeys").(models.ProviderKeys); ok {
+		return keys
+	}
+
+	return models.ProviderKeys{
+		Alchemy:   c.Get("X-Alchemy-API-Key", ""),
+		CoinGecko: c.Get("X-CoinGecko-API-Key", ""),
+		Etherscan: c.Get("X-Etherscan-API-Key", ""),
+		Infura:    c.Get("X-Infura-API-Key", ""),
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ProviderPolicyHandler struct {
+	policyService *services.ProviderPolicyService
+}
+
+func NewProviderPolicyHandler(policyService *services.ProviderPolicyService) *ProviderPolicyHandler {
+	return &ProviderPolicyHandler{
+		policyService: policyService,
+	}
+}
+
+// GetProviderPolicies handles GET /admin/provider-policies
+func (h *ProviderPolicyHandler) GetProviderPolicies(c *fiber.Ctx) error {
+	policies, err := h.policyService.List(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, policies, fiber.Map{
+		"total": len(policies),
+	})
+}
+
+// UpsertProviderPolicy handles PUT /admin/provider-policies. A policy for provider '*'
+// with no chain and disabled set stops all bridge and swap quotes.
+func (h *ProviderPolicyHandler) UpsertProviderPolicy(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.UpsertProviderPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	policy, err := h.policyService.Upsert(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, policy)
+}
+
+// DeleteProviderPolicy handles DELETE /admin/provider-policies/:id
+func (h *ProviderPolicyHandler) DeleteProviderPolicy(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id", "policy")
+	if err != nil {
+		return err
+	}
+
+	if err := h.policyService.Delete(c.Context(), id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type QuotaHandler struct {
+	quotaService *services.QuotaService
+}
+
+func NewQuotaHandler(quotaService *services.QuotaService) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+	}
+}
+
+// GetQuota handles GET /quota, the user's plan tier and usage of each limited resource
+func (h *QuotaHandler) GetQuota(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	quota, err := h.quotaService.GetQuota(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, quota)
+}
+
+// SetUserPlan handles PUT /admin/users/:userId/plan, changing a user's tier or
+// overriding its limits
+func (h *QuotaHandler) SetUserPlan(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	userID, err := uuidParam(c, "userId", "user")
+	if err != nil {
+		return err
+	}
+
+	var req models.SetUserPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	quota, err := h.quotaService.SetPlan(c.Context(), adminID, userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, quota)
+}
\ No newline at end of file
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const realtimePingInterval = 30 * time.Second
+
+type RealtimeHandler struct {
+	hub            *events.Hub
+	allowedOrigins []string // browser origins allowed to connect, as ALLOW_ORIGINS
+}
+
+func NewRealtimeHandler(hub *events.Hub, allowedOrigins []string) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:            hub,
+		allowedOrigins: allowedOrigins,
+	}
+}
+
+// Stream handles GET /ws, upgrading to a websocket that streams the user's realtime events
+func (h *RealtimeHandler) Stream(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	if !websocket.IsUpgrade(c) {
+		return errors.New("UPGRADE_REQUIRED", "Websocket upgrade required", fiber.StatusUpgradeRequired)
+	}
+
+	err := websocket.Upgrade(c, h.allowedOrigins, func(conn *websocket.Conn) {
+		subscription, unsubscribe := h.hub.Subscribe(userID)
+		defer unsubscribe()
+
+		// Clients don't send anything meaningful; reading is only to notice them leaving
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(realtimePingInterval)
+		defer ping.Stop()
+
+		logger.Debug("Realtime client connected", "userID", userID)
+		defer logger.Debug("Realtime client disconnected", "userID", userID)
+
+		for {
+			select {
+			case <-closed:
+				return
+			case event, ok := <-subscription:
+				if !ok {
+					return
+				}
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			case <-ping.C:
+				if err := conn.WriteMessage(websocket.OpPing, nil); err != nil {
+					return
+				}
+			}
+		}
+	})
+	switch {
+	case stderrors.Is(err, websocket.ErrOriginNotAllowed):
+		return errors.Forbidden("Origin not allowed")
+	case err != nil:
+		return errors.BadRequest("Invalid websocket handshake")
+	}
+	return nil
+}
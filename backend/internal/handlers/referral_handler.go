@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReferralHandler struct {
+	referralService *services.ReferralService
+}
+
+func NewReferralHandler(referralService *services.ReferralService) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+	}
+}
+
+// GetReferrals handles GET /referrals, the user's referral code and how it has done
+func (h *ReferralHandler) GetReferrals(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	stats, err := h.referralService.GetStats(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, stats)
+}
+
+// ClaimReferral handles POST /referrals/claim, crediting another user's code with the
+// user's signup
+func (h *ReferralHandler) ClaimReferral(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.ClaimReferralRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	referral, err := h.referralService.Claim(c.Context(), userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(fiber.StatusCreated), referral)
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetPreferences handles GET /reports/preferences
+func (h *ReportHandler) GetPreferences(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	prefs, err := h.reportService.GetPreferences(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, prefs)
+}
+
+// UpdatePreferences handles PUT /reports/preferences
+func (h *ReportHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req models.UpdateReportPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	prefs, err := h.reportService.UpdatePreferences(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, prefs)
+}
+
+// GetStatements handles GET /reports/statements
+func (h *ReportHandler) GetStatements(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	limit, err := strconv.Atoi(c.Query("limit", "24"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 24
+	}
+	offset, err := strconv.Atoi(c.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	statements, err := h.reportService.GetStatements(c.Context(), userID, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, statements, fiber.Map{
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GenerateStatement handles POST /reports/statements
+func (h *ReportHandler) GenerateStatement(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req models.GenerateStatementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	statement, err := h.reportService.GenerateStatement(c.Context(), userID, req.Month)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), statement)
+}
+
+// DownloadStatement handles GET /reports/statements/:id/download
+func (h *ReportHandler) DownloadStatement(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	statementID, err := uuidParam(c, "id", "statement")
+	if err != nil {
+		return err
+	}
+
+	statement, pdf, err := h.reportService.GetStatementFile(c.Context(), userID, statementID)
+	if err != nil {
+		return err
+	}
+
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"statement_%s.pdf\"", statement.PeriodStart.Format("2006-01")))
+	return c.Send(pdf)
+}
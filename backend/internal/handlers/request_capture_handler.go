@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/middleware"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxRequestCaptures caps how many captures one listing returns
+const maxRequestCaptures = 200
+
+type RequestCaptureHandler struct {
+	captures *middleware.RequestCaptures
+}
+
+// NewRequestCaptureHandler serves the captures kept by the request logger; captures is
+// nil when capturing is turned off
+func NewRequestCaptureHandler(captures *middleware.RequestCaptures) *RequestCaptureHandler {
+	return &RequestCaptureHandler{
+		captures: captures,
+	}
+}
+
+// GetRequestCaptures handles GET /admin/request-captures, newest first, optionally
+// filtered by status, path prefix, request ID or user
+func (h *RequestCaptureHandler) GetRequestCaptures(c *fiber.Ctx) error {
+	if h.captures == nil {
+		return errors.New("CAPTURE_DISABLED", "Request capturing is disabled; set CAPTURE_FAILED_REQUESTS to enable it", fiber.StatusNotFound)
+	}
+
+	filter := models.RequestCaptureFilter{
+		PathPrefix: c.Query("path"),
+		RequestID:  c.Query("requestId"),
+		Limit:      50,
+	}
+	if raw := c.Query("status"); raw != "" {
+		status, err := strconv.Atoi(raw)
+		if err != nil || status < 400 || status > 599 {
+			return errors.BadRequest("Status must be an HTTP error status")
+		}
+		filter.Status = status
+	}
+	if raw := c.Query("userId"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			return errors.BadRequest("Invalid user ID")
+		}
+		filter.UserID = &userID
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxRequestCaptures {
+			return errors.BadRequest("Limit must be between 1 and 200")
+		}
+		filter.Limit = limit
+	}
+
+	captures := h.captures.List(filter)
+	return respondWithMeta(c, captures, fiber.Map{
+		"total": len(captures),
+	})
+}
+
+// GetRequestCapture handles GET /admin/request-captures/:id
+func (h *RequestCaptureHandler) GetRequestCapture(c *fiber.Ctx) error {
+	if h.captures == nil {
+		return errors.New("CAPTURE_DISABLED", "Request capturing is disabled; set CAPTURE_FAILED_REQUESTS to enable it", fiber.StatusNotFound)
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.BadRequest("Invalid capture ID")
+	}
+	capture := h.captures.Get(id)
+	if capture == nil {
+		return errors.NotFound("Request capture")
+	}
+
+	return respond(c, capture)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/middleware"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// EnvelopeHeader lets /v1 clients ask for the standard envelope from endpoints that
+// still answer in their old shape by default, by sending "standard". Those shapes are
+// deprecated; /v2 always answers in the envelope.
+const EnvelopeHeader = "X-Response-Envelope"
+
+// Envelope is the standard /v1 response body. Successful responses carry data, and meta
+// for lists; failed ones carry errors only.
+type Envelope struct {
+	Data   any                `json:"data,omitempty"`
+	Meta   any                `json:"meta,omitempty"`
+	Errors []*errors.AppError `json:"errors,omitempty"`
+}
+
+// dataEnvelope is Envelope for successful responses, whose data may be null
+type dataEnvelope struct {
+	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"`
+}
+
+// legacyError is the deprecated error body: the error's fields at the top level, with
+// the standard errors list alongside so clients can move over before the fields go
+type legacyError struct {
+	*errors.AppError
+	Errors []*errors.AppError `json:"errors"`
+}
+
+// respond writes data in the standard envelope. Set a status other than 200 on c first.
+func respond(c *fiber.Ctx, data any) error {
+	return c.JSON(dataEnvelope{Data: data})
+}
+
+// respondWithMeta writes a page of a list in the standard envelope, with its paging and
+// totals in meta
+func respondWithMeta(c *fiber.Ctx, data, meta any) error {
+	return c.JSON(dataEnvelope{Data: data, Meta: meta})
+}
+
+// respondLegacy writes an endpoint's pre-envelope body as it always has, marked
+// deprecated, or as the envelope's data for clients that asked for it
+func respondLegacy(c *fiber.Ctx, body any) error {
+	if wantsEnvelope(c) {
+		return respond(c, body)
+	}
+	c.Set("Deprecation", "true")
+	return c.JSON(body)
+}
+
+// RespondError writes an error with the given status, in the standard envelope for
+// clients that asked for it and in the deprecated shape otherwise
+func RespondError(c *fiber.Ctx, status int, err *errors.AppError) error {
+	c.Status(status)
+	if wantsEnvelope(c) {
+		return c.JSON(Envelope{Errors: []*errors.AppError{err}})
+	}
+	return c.JSON(legacyError{AppError: err, Errors: []*errors.AppError{err}})
+}
+
+func wantsEnvelope(c *fiber.Ctx) bool {
+	return middleware.RequestAPIVersion(c) >= middleware.APIVersion2 ||
+		strings.EqualFold(c.Get(EnvelopeHeader), "standard")
+}
\ No newline at end of file
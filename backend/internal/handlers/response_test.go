@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseEnvelope(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		appErr := err.(*errors.AppError)
+		return RespondError(c, appErr.Status, appErr)
+	}})
+	app.Get("/item", func(c *fiber.Ctx) error {
+		return respond(c.Status(fiber.StatusCreated), fiber.Map{"id": 1})
+	})
+	app.Get("/empty", func(c *fiber.Ctx) error {
+		return respond(c, nil)
+	})
+	app.Get("/list", func(c *fiber.Ctx) error {
+		return respondWithMeta(c, []int{1, 2}, fiber.Map{"total": 2})
+	})
+	app.Get("/legacy", func(c *fiber.Ctx) error {
+		return respondLegacy(c, fiber.Map{"id": 1})
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return errors.NotFound("Alert")
+	})
+
+	get := func(path string, standard bool) (int, string, map[string]interface{}) {
+		req := httptest.NewRequest("GET", path, nil)
+		if standard {
+			req.Header.Set(EnvelopeHeader, "standard")
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &decoded), string(body))
+		return resp.StatusCode, resp.Header.Get("Deprecation"), decoded
+	}
+
+	status, _, body := get("/item", false)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"id": 1.0}}, body)
+
+	// Data may be null but is always there
+	_, _, body = get("/empty", false)
+	assert.Contains(t, body, "data")
+	assert.Nil(t, body["data"])
+
+	_, _, body = get("/list", false)
+	assert.Equal(t, []interface{}{1.0, 2.0}, body["data"])
+	assert.Equal(t, map[string]interface{}{"total": 2.0}, body["meta"])
+
+	// Old shapes are served as before, marked deprecated, unless the envelope is asked for
+	_, deprecation, body := get("/legacy", false)
+	assert.Equal(t, "true", deprecation)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, body)
+	_, deprecation, body = get("/legacy", true)
+	assert.Empty(t, deprecation)
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"id": 1.0}}, body)
+
+	// Errors keep their old fields next to the standard list
+	status, _, body = get("/fail", false)
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, "NOT_FOUND", body["code"])
+	assert.Equal(t, "Alert not found", body["message"])
+	assert.Len(t, body["errors"], 1)
+	_, _, body = get("/fail", true)
+	assert.Equal(t, map[string]interface{}{"errors": []interface{}{
+		map[string]interface{}{"code": "NOT_FOUND", "message": "Alert not found"},
+	}}, body)
+}
\ No newline at end of file
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RewardLockHandler struct {
+	rewardLockService *services.RewardLockService
+}
+
+func NewRewardLockHandler(rewardLockService *services.RewardLockService) *RewardLockHandler {
+	return &RewardLockHandler{
+		rewardLockService: rewardLockService,
+	}
+}
+
+// GetLocks handles GET /positions/locks
+func (h *RewardLockHandler) GetLocks(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	locks, err := h.rewardLockService.GetLocks(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, locks)
+}
+
+// SyncLocks handles POST /positions/locks/sync, re-reading vote-escrow locks from the chain
+func (h *RewardLockHandler) SyncLocks(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	locks, err := h.rewardLockService.SyncLocks(c.Context(), userID, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, locks)
+}
+
+// CreateVestingSchedule handles POST /positions/locks
+func (h *RewardLockHandler) CreateVestingSchedule(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateVestingScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	lock, err := h.rewardLockService.CreateVestingSchedule(c.Context(), userID, req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), lock)
+}
+
+// DeleteLock handles DELETE /positions/locks/:id
+func (h *RewardLockHandler) DeleteLock(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	lockID, err := uuidParam(c, "id", "lock")
+	if err != nil {
+		return err
+	}
+
+	if err := h.rewardLockService.DeleteLock(c.Context(), userID, lockID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetCalendar handles GET /positions/locks/calendar?months=12
+func (h *RewardLockHandler) GetCalendar(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	calendar, err := h.rewardLockService.GetCalendar(c.Context(), userID, c.QueryInt("months", 0))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, calendar)
+}
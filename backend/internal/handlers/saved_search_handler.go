@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SavedSearchHandler struct {
+	savedSearchService *services.SavedSearchService
+}
+
+func NewSavedSearchHandler(savedSearchService *services.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		savedSearchService: savedSearchService,
+	}
+}
+
+// GetSavedSearches handles GET /saved-searches, optionally filtered with kind=yield_pools|transactions
+func (h *SavedSearchHandler) GetSavedSearches(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	searches, err := h.savedSearchService.List(c.Context(), userID, c.Query("kind"))
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, searches, fiber.Map{
+		"total": len(searches),
+	})
+}
+
+// CreateSavedSearch handles POST /saved-searches. Params are the query parameters of
+// GET /yield/pools/search or GET /transactions/search, depending on kind.
+func (h *SavedSearchHandler) CreateSavedSearch(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateSavedSearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	search, err := h.savedSearchService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), search)
+}
+
+// GetSavedSearch handles GET /saved-searches/:id
+func (h *SavedSearchHandler) GetSavedSearch(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "saved search")
+	if err != nil {
+		return err
+	}
+
+	search, err := h.savedSearchService.Get(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, search)
+}
+
+// GetSharedSavedSearch handles GET /saved-searches/shared/:token, which any signed-in
+// user with the link can read
+func (h *SavedSearchHandler) GetSharedSavedSearch(c *fiber.Ctx) error {
+	search, err := h.savedSearchService.GetShared(c.Context(), c.Params("token"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, search)
+}
+
+// UpdateSavedSearch handles PUT /saved-searches/:id
+func (h *SavedSearchHandler) UpdateSavedSearch(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "saved search")
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateSavedSearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	search, err := h.savedSearchService.Update(c.Context(), userID, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, search)
+}
+
+// DeleteSavedSearch handles DELETE /saved-searches/:id
+func (h *SavedSearchHandler) DeleteSavedSearch(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "saved search")
+	if err != nil {
+		return err
+	}
+
+	if err := h.savedSearchService.Delete(c.Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SecretHandler struct {
+	secretRotationService *services.SecretRotationService
+}
+
+func NewSecretHandler(secretRotationService *services.SecretRotationService) *SecretHandler {
+	return &SecretHandler{
+		secretRotationService: secretRotationService,
+	}
+}
+
+// RotateSecrets handles POST /admin/secrets/rotate, re-sealing stored secrets with the
+// current encryption key
+func (h *SecretHandler) RotateSecrets(c *fiber.Ctx) error {
+	result, err := h.secretRotationService.Rotate(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StakingHandler struct {
+	stakingService *services.StakingService
+}
+
+func NewStakingHandler(stakingService *services.StakingService) *StakingHandler {
+	return &StakingHandler{
+		stakingService: stakingService,
+	}
+}
+
+// GetStakingPositions handles GET /positions/staking
+func (h *StakingHandler) GetStakingPositions(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+	keys := providerKeys(c)
+
+	positions, err := h.stakingService.GetPositions(c.Context(), userID, c.Query("address"), keys.Alchemy, keys.CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, positions)
+}
+
+// AddValidator handles POST /positions/staking/validators
+func (h *StakingHandler) AddValidator(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req models.AddStakingValidatorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	validator, err := h.stakingService.AddValidator(c.Context(), userID, req.ValidatorIndex, req.Label)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), validator)
+}
+
+// RemoveValidator handles DELETE /positions/staking/validators/:index
+func (h *StakingHandler) RemoveValidator(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	index, err := strconv.ParseInt(c.Params("index"), 10, 64)
+	if err != nil {
+		return errors.BadRequest("Invalid validator index")
+	}
+
+	if err := h.stakingService.RemoveValidator(c.Context(), userID, index); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
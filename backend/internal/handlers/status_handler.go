@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// statusMaxAge is how long clients and CDNs may cache the status, in seconds; it
+// matches how long the service caches it
+const statusMaxAge = 30
+
+type StatusHandler struct {
+	statusService *services.StatusService
+}
+
+func NewStatusHandler(statusService *services.StatusService) *StatusHandler {
+	return &StatusHandler{
+		statusService: statusService,
+	}
+}
+
+// GetStatus handles GET /status, the health of each subsystem for status pages and the
+// frontend's degradation banner
+func (h *StatusHandler) GetStatus(c *fiber.Ctx) error {
+	status, err := h.statusService.GetStatus(c.Context())
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", statusMaxAge))
+	return respond(c, status)
+}
\ No newline at end of file
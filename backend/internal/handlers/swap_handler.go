@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/services"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type SwapHandler struct {
 	swapService *services.SwapService
+	analytics   services.EventTracker
 }
 
 func NewSwapHandler(swapService *services.SwapService) *SwapHandler {
@@ -16,6 +19,11 @@ func NewSwapHandler(swapService *services.SwapService) *SwapHandler {
 	}
 }
 
+// SetAnalytics reports quotes as quote_requested usage events
+func (h *SwapHandler) SetAnalytics(analytics services.EventTracker) {
+	h.analytics = analytics
+}
+
 // GetSwapQuote handles POST /swap/quote
 func (h *SwapHandler) GetSwapQuote(c *fiber.Ctx) error {
 	var req services.SwapQuoteRequest
@@ -42,14 +50,48 @@ func (h *SwapHandler) GetSwapQuote(c *fiber.Ctx) error {
 		req.Slippage = 0.5
 	}
 
-	// Get swap quotes
-	quotes, err := h.swapService.GetQuotes(c.Context(), req)
+	// Get swap quotes, sharing them with the user's identical requests in flight
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	quotes, err := h.swapService.GetUserQuotes(c.Context(), userID, req)
 	if err != nil {
 		return err
 	}
+	if ok && h.analytics != nil {
+		h.analytics.Track(userID, models.AnalyticsQuoteRequested, map[string]interface{}{
+			"kind":   "swap",
+			"chain":  req.ChainID,
+			"quotes": len(quotes),
+		})
+	}
 
 	// Return quotes as array (frontend expects array directly)
-	return c.JSON(quotes)
+	return respondLegacy(c, quotes)
+}
+
+// EstimatePriceImpact handles POST /swap/price-impact
+func (h *SwapHandler) EstimatePriceImpact(c *fiber.Ctx) error {
+	var req services.PriceImpactRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	// Validate request
+	if req.ChainID == 0 {
+		return errors.BadRequest("ChainID is required")
+	}
+	if req.FromToken == "" || req.ToToken == "" {
+		return errors.BadRequest("FromToken and ToToken are required")
+	}
+	if req.FromAmount == "" {
+		return errors.BadRequest("FromAmount is required")
+	}
+
+	estimate, err := h.swapService.EstimatePriceImpact(c.Context(), req, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c, estimate)
 }
 
 // ExecuteSwap handles POST /swap/execute
@@ -71,7 +113,7 @@ func (h *SwapHandler) ExecuteSwap(c *fiber.Ctx) error {
 	// For now, return mock transaction hash
 	mockTxHash := "0x" + generateSwapTxHash()
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"txHash": mockTxHash,
 	})
 }
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TeamHandler struct {
+	teamService *services.TeamService
+}
+
+func NewTeamHandler(teamService *services.TeamService) *TeamHandler {
+	return &TeamHandler{
+		teamService: teamService,
+	}
+}
+
+// GetTeams handles GET /teams, returning the teams the user is a member of
+func (h *TeamHandler) GetTeams(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	teams, err := h.teamService.GetTeams(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, teams)
+}
+
+// CreateTeam handles POST /teams, creating a team owned by the user
+func (h *TeamHandler) CreateTeam(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateTeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	team, err := h.teamService.CreateTeam(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), team)
+}
+
+// GetMembers handles GET /teams/:teamId/members
+func (h *TeamHandler) GetMembers(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	members, err := h.teamService.GetMembers(c.Context(), userID, teamID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, members)
+}
+
+// AddMember handles POST /teams/:teamId/members, adding a user by wallet address.
+// Owners only.
+func (h *TeamHandler) AddMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	var req models.AddTeamMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	member, err := h.teamService.AddMember(c.Context(), userID, teamID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), member)
+}
+
+// RemoveMember handles DELETE /teams/:teamId/members/:userId. Owners can remove anyone;
+// members can remove themselves. When another owner must approve the removal it
+// responds 202 with the pending action.
+func (h *TeamHandler) RemoveMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+	memberID, err := uuidParam(c, "userId", "user")
+	if err != nil {
+		return err
+	}
+
+	pending, err := h.teamService.RemoveMember(c.Context(), userID, teamID, memberID)
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		return respond(c.Status(fiber.StatusAccepted), pending)
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetPolicies handles GET /teams/:teamId/escalation-policies
+func (h *TeamHandler) GetPolicies(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	policies, err := h.teamService.GetPolicies(c.Context(), userID, teamID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, policies)
+}
+
+// CreatePolicy handles POST /teams/:teamId/escalation-policies. Owners only.
+func (h *TeamHandler) CreatePolicy(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	var req models.EscalationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	policy, err := h.teamService.CreatePolicy(c.Context(), userID, teamID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), policy)
+}
+
+// UpdatePolicy handles PUT /teams/:teamId/escalation-policies/:policyId, replacing the
+// policy's name and steps. Owners only; responds 202 with the pending action when
+// another owner must approve the change.
+func (h *TeamHandler) UpdatePolicy(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+	policyID, err := uuidParam(c, "policyId", "policy")
+	if err != nil {
+		return err
+	}
+
+	var req models.EscalationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	policy, pending, err := h.teamService.UpdatePolicy(c.Context(), userID, teamID, policyID, &req)
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		return respond(c.Status(fiber.StatusAccepted), pending)
+	}
+
+	return respond(c, policy)
+}
+
+// DeletePolicy handles DELETE /teams/:teamId/escalation-policies/:policyId. Owners only;
+// responds 202 with the pending action when another owner must approve the deletion.
+func (h *TeamHandler) DeletePolicy(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+	policyID, err := uuidParam(c, "policyId", "policy")
+	if err != nil {
+		return err
+	}
+
+	pending, err := h.teamService.DeletePolicy(c.Context(), userID, teamID, policyID)
+	if err != nil {
+		return err
+	}
+	if pending != nil {
+		return respond(c.Status(fiber.StatusAccepted), pending)
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetShifts handles GET /teams/:teamId/on-call, listing shifts overlapping the
+// optional from and to (RFC3339) range, the next two weeks by default
+func (h *TeamHandler) GetShifts(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	var from, to time.Time
+	if raw := c.Query("from"); raw != "" {
+		if from, err = time.Parse(time.RFC3339, raw); err != nil {
+			return errors.BadRequest("Invalid from time, expected RFC3339")
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if to, err = time.Parse(time.RFC3339, raw); err != nil {
+			return errors.BadRequest("Invalid to time, expected RFC3339")
+		}
+	}
+
+	shifts, err := h.teamService.GetShifts(c.Context(), userID, teamID, from, to)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, shifts)
+}
+
+// GetOnCall handles GET /teams/:teamId/on-call/current, returning the shift on call
+// now or null
+func (h *TeamHandler) GetOnCall(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	shift, err := h.teamService.GetOnCall(c.Context(), userID, teamID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, shift)
+}
+
+// CreateShift handles POST /teams/:teamId/on-call. Owners only.
+func (h *TeamHandler) CreateShift(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	var req models.CreateOnCallShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	shift, err := h.teamService.CreateShift(c.Context(), userID, teamID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), shift)
+}
+
+// DeleteShift handles DELETE /teams/:teamId/on-call/:shiftId. Owners only.
+func (h *TeamHandler) DeleteShift(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+	shiftID, err := uuidParam(c, "shiftId", "shift")
+	if err != nil {
+		return err
+	}
+
+	if err := h.teamService.DeleteShift(c.Context(), userID, teamID, shiftID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetEscalations handles GET /teams/:teamId/escalations, optionally filtered by status
+func (h *TeamHandler) GetEscalations(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	escalations, err := h.teamService.GetEscalations(c.Context(), userID, teamID, c.Query("status"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c, escalations)
+}
+
+// AcknowledgeEscalation handles POST /teams/:teamId/escalations/:escalationId/ack,
+// stopping the escalation's remaining steps
+func (h *TeamHandler) AcknowledgeEscalation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+	escalationID, err := uuidParam(c, "escalationId", "escalation")
+	if err != nil {
+		return err
+	}
+
+	escalation, err := h.teamService.Acknowledge(c.Context(), userID, teamID, escalationID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, escalation)
+}
+
+// SetAlertPolicy handles PUT /alerts/:alertId/escalation-policy, routing the alert's
+// triggers through a team escalation policy, or clearing it with a null policy_id
+func (h *TeamHandler) SetAlertPolicy(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	alertID, err := uuidParam(c, "alertId", "alert")
+	if err != nil {
+		return err
+	}
+
+	var req models.SetEscalationPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	alert, err := h.teamService.SetAlertPolicy(c.Context(), userID, alertID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, alert)
+}
+
+// GetPendingActions handles GET /teams/:teamId/pending-actions, listing the team's
+// latest requested changes
+func (h *TeamHandler) GetPendingActions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+
+	actions, err := h.teamService.GetPendingActions(c.Context(), userID, teamID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, actions)
+}
+
+// ApproveAction handles POST /teams/:teamId/pending-actions/:actionId/approve. Owners
+// other than the requester only.
+func (h *TeamHandler) ApproveAction(c *fiber.Ctx) error {
+	return h.decideAction(c, h.teamService.ApproveAction)
+}
+
+// RejectAction handles POST /teams/:teamId/pending-actions/:actionId/reject. Owners only.
+func (h *TeamHandler) RejectAction(c *fiber.Ctx) error {
+	return h.decideAction(c, h.teamService.RejectAction)
+}
+
+func (h *TeamHandler) decideAction(c *fiber.Ctx, decide func(ctx context.Context, userID, teamID, actionID uuid.UUID) (*models.TeamPendingAction, error)) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	teamID, err := uuidParam(c, "teamId", "team")
+	if err != nil {
+		return err
+	}
+	actionID, err := uuidParam(c, "actionId", "action")
+	if err != nil {
+		return err
+	}
+
+	action, err := decide(c.Context(), userID, teamID, actionID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, action)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// tokenViewPriceBoost is how long viewing a token keeps its price refreshed on every run
+const tokenViewPriceBoost = time.Hour
+
+type TokenHandler struct {
+	tokenMetadataRepo   repos.TokenMetadataRepository
+	priceRefreshService *services.PriceRefreshService
+	holderService       *services.TokenHolderService
+	contractService     *services.ContractService
+}
+
+func NewTokenHandler(tokenMetadataRepo repos.TokenMetadataRepository, priceRefreshService *services.PriceRefreshService) *TokenHandler {
+	return &TokenHandler{
+		tokenMetadataRepo:   tokenMetadataRepo,
+		priceRefreshService: priceRefreshService,
+	}
+}
+
+// SetHolders adds holder concentration and the user's share of the supply to token details
+func (h *TokenHandler) SetHolders(holderService *services.TokenHolderService) {
+	h.holderService = holderService
+}
+
+// SetContracts adds what the explorer knows of the token's contract to token details
+func (h *TokenHandler) SetContracts(contractService *services.ContractService) {
+	h.contractService = contractService
+}
+
+// GetToken handles GET /tokens/:id
+func (h *TokenHandler) GetToken(c *fiber.Ctx) error {
+	tokenID, err := uuidParam(c, "id", "token")
+	if err != nil {
+		return err
+	}
+
+	token, err := h.tokenMetadataRepo.GetToken(c.Context(), tokenID)
+	if err != nil {
+		if stderrors.Is(err, repos.ErrTokenNotFound) {
+			return errors.NotFound("Token")
+		}
+		logger.Error("Failed to get token",
+			"error", err.Error(),
+			"tokenID", tokenID,
+		)
+		return errors.Internal("Failed to get token")
+	}
+
+	if h.holderService != nil {
+		userID, _ := c.Locals("userID").(uuid.UUID)
+		if err := h.holderService.Attach(c.Context(), token, userID); err != nil {
+			logger.Warn("Failed to get token holders", "error", err, "tokenID", tokenID)
+		}
+	}
+
+	if h.contractService != nil && !token.IsNative() {
+		if token.Contract, err = h.contractService.Get(c.Context(), token.ChainID, token.Address); err != nil {
+			logger.Warn("Failed to get token contract", "error", err, "tokenID", tokenID)
+		}
+	}
+
+	// Viewed tokens get fresh prices even when they're in the long tail
+	if err := h.tokenMetadataRepo.BoostPriceRefresh(c.Context(), tokenID, time.Now().Add(tokenViewPriceBoost)); err != nil {
+		logger.Warn("Failed to boost token price refresh", "error", err, "tokenID", tokenID)
+	}
+
+	return respondLegacy(c, token)
+}
+
+// RefreshPrice handles POST /tokens/:id/refresh-price
+func (h *TokenHandler) RefreshPrice(c *fiber.Ctx) error {
+	tokenID, err := uuidParam(c, "id", "token")
+	if err != nil {
+		return err
+	}
+
+	price, err := h.priceRefreshService.RefreshPrice(c.Context(), tokenID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, price)
+}
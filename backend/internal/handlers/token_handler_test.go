@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTokenRepo fails every token lookup with err
+type failingTokenRepo struct {
+	repos.TokenMetadataRepository
+	err error
+}
+
+func (r *failingTokenRepo) GetToken(ctx context.Context, tokenID uuid.UUID) (*models.Token, error) {
+	return nil, r.err
+}
+
+func TestTokenHandler_GetTokenErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err    error
+		status int
+	}{
+		"missing token": {repos.ErrTokenNotFound, http.StatusNotFound},
+		"failed lookup": {stderrors.New("connection refused"), http.StatusInternalServerError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			handler := NewTokenHandler(&failingTokenRepo{err: tc.err}, nil)
+			app := setupTestApp()
+			app.Get("/tokens/:id", handler.GetToken)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/tokens/"+uuid.New().String(), nil))
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
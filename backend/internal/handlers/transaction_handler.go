@@ -3,9 +3,11 @@ package handlers
 import (
 	"strconv"
 
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/services"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type TransactionHandler struct {
@@ -20,19 +22,15 @@ func NewTransactionHandler(transactionService *services.TransactionService) *Tra
 
 // GetTransactions handles GET /transactions/:address
 func (h *TransactionHandler) GetTransactions(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse query parameters
-	var chainID *int
-	if chainParam := c.Query("chainId"); chainParam != "" {
-		chain, err := strconv.Atoi(chainParam)
-		if err != nil {
-			return errors.BadRequest("Invalid chainId")
-		}
-		chainID = &chain
+	chainID, err := optionalChainIDQuery(c)
+	if err != nil {
+		return err
 	}
 
 	var txType *string
@@ -59,34 +57,77 @@ func (h *TransactionHandler) GetTransactions(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	// Extract API keys from request headers
-	alchemyAPIKey := c.Get("X-Alchemy-API-Key", "")
-	coinGeckoAPIKey := c.Get("X-CoinGecko-API-Key", "")
+	// Resolve provider API keys (request headers, then the user's stored keys)
+	keys := providerKeys(c)
+	alchemyAPIKey, coinGeckoAPIKey := keys.Alchemy, keys.CoinGecko
+
+	userID := c.Locals("userID").(uuid.UUID)
 
 	// Get transactions
-	transactions, err := h.transactionService.GetTransactions(c.Context(), address, chainID, txType, page, limit, alchemyAPIKey, coinGeckoAPIKey)
+	transactions, err := h.transactionService.GetTransactions(c.Context(), userID, address, chainID, txType, page, limit, alchemyAPIKey, coinGeckoAPIKey)
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c, transactions)
+}
+
+// SearchTransactions handles GET /transactions/search
+func (h *TransactionHandler) SearchTransactions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	result, err := h.transactionService.SearchTransactions(c.Context(), userID, c.Query("q"), c.Query("cursor"), limit)
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c, result)
+}
+
+// SetAnnotation handles PUT /transactions/:hash/annotation
+func (h *TransactionHandler) SetAnnotation(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req models.UpdateTransactionAnnotationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	annotation, err := h.transactionService.SetAnnotation(c.Context(), userID, c.Params("hash"), &req)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(transactions)
+	return respond(c, annotation)
+}
+
+// DeleteAnnotation handles DELETE /transactions/:hash/annotation
+func (h *TransactionHandler) DeleteAnnotation(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	if err := h.transactionService.DeleteAnnotation(c.Context(), userID, c.Params("hash")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
 }
 
 // GetApprovals handles GET /transactions/:address/approvals
 func (h *TransactionHandler) GetApprovals(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address is required")
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse query parameters
-	var chainID *int
-	if chainParam := c.Query("chainId"); chainParam != "" {
-		chain, err := strconv.Atoi(chainParam)
-		if err != nil {
-			return errors.BadRequest("Invalid chainId")
-		}
-		chainID = &chain
+	chainID, err := optionalChainIDQuery(c)
+	if err != nil {
+		return err
 	}
 
 	activeOnly := c.Query("active", "true") == "true"
@@ -97,24 +138,54 @@ func (h *TransactionHandler) GetApprovals(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"approvals": approvals,
 	})
 }
 
+// GetProtocolInteractions handles GET /wallets/:address/protocols, summarizing the
+// protocols the wallet has dealt with from its imported transactions
+func (h *TransactionHandler) GetProtocolInteractions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	address, err := normalizeAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	interactions, err := h.transactionService.GetProtocolInteractions(c.Context(), userID, address)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, interactions)
+}
+
 // RevokeApproval handles DELETE /transactions/:address/approvals/:token
 func (h *TransactionHandler) RevokeApproval(c *fiber.Ctx) error {
-	address := c.Params("address")
 	token := c.Params("token")
 	spender := c.Query("spender")
 
-	if address == "" || token == "" || spender == "" {
+	if c.Params("address") == "" || token == "" || spender == "" {
 		return errors.BadRequest("Address, token, and spender are required")
 	}
 
+	address, err := normalizeEVMAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+	if token, err = normalizeEVMAddress(token); err != nil {
+		return err
+	}
+	if spender, err = normalizeEVMAddress(spender); err != nil {
+		return err
+	}
+
 	// TODO: Validate that the authenticated user owns this address
 	authAddress := c.Locals("address").(string)
-	if authAddress != address {
+	if !sameAddress(authAddress, address) {
 		return errors.Forbidden("You can only revoke approvals for your own address")
 	}
 
@@ -124,7 +195,7 @@ func (h *TransactionHandler) RevokeApproval(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"txHash": txHash,
 	})
 }
\ No newline at end of file
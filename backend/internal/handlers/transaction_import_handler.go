@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"io"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TransactionImportHandler struct {
+	importService *services.TransactionImportService
+}
+
+func NewTransactionImportHandler(importService *services.TransactionImportService) *TransactionImportHandler {
+	return &TransactionImportHandler{
+		importService: importService,
+	}
+}
+
+// UploadImport handles POST /transactions/import, a multipart form with the CSV as
+// "file" and the target wallet as "wallet_id"
+func (h *TransactionImportHandler) UploadImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	walletID, err := uuid.Parse(c.FormValue("wallet_id"))
+	if err != nil {
+		return errors.BadRequest("Invalid wallet ID")
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return errors.BadRequest("A CSV file is required")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return errors.BadRequest("Failed to read file")
+	}
+	defer file.Close()
+
+	// Read one byte past the limit so the service can reject oversized files
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxImportSize+1))
+	if err != nil {
+		return errors.BadRequest("Failed to read file")
+	}
+
+	preview, err := h.importService.Upload(c.Context(), userID, walletID, header.Filename, data)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), preview)
+}
+
+// GetImport handles GET /transactions/import/:id
+func (h *TransactionImportHandler) GetImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	importID, err := uuidParam(c, "id", "import")
+	if err != nil {
+		return err
+	}
+
+	imp, err := h.importService.Get(c.Context(), userID, importID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, imp)
+}
+
+// PreviewImport handles POST /transactions/import/:id/preview
+func (h *TransactionImportHandler) PreviewImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	importID, err := uuidParam(c, "id", "import")
+	if err != nil {
+		return err
+	}
+
+	var req models.PreviewTransactionImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	preview, err := h.importService.Preview(c.Context(), userID, importID, req.Mapping)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, preview)
+}
+
+// CommitImport handles POST /transactions/import/:id/commit
+func (h *TransactionImportHandler) CommitImport(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	importID, err := uuidParam(c, "id", "import")
+	if err != nil {
+		return err
+	}
+
+	var req models.CommitTransactionImportRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errors.BadRequest("Invalid request body")
+		}
+	}
+
+	imp, err := h.importService.Commit(c.Context(), userID, importID, req.SkipInvalid)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, imp)
+}
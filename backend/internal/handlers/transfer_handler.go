@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TransferHandler struct {
+	planner *services.TransferPlanner
+}
+
+func NewTransferHandler(planner *services.TransferPlanner) *TransferHandler {
+	return &TransferHandler{planner: planner}
+}
+
+// PlanTransfer handles POST /transfers/plan. It compares the ways the user's holdings
+// can deliver an amount of a token to any address: sending it directly, or swapping or
+// bridging into it first.
+func (h *TransferHandler) PlanTransfer(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	var req services.TransferPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	plan, err := h.planner.Plan(c.Context(), userID, req, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, plan)
+}
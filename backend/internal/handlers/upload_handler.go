@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UploadHandler struct {
+	uploadService *services.UploadService
+}
+
+func NewUploadHandler(uploadService *services.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// GetUpload handles GET /uploads/:id, returning the file's details and a signed download URL
+func (h *UploadHandler) GetUpload(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(uuid.UUID)
+
+	uploadID, err := uuidParam(c, "id", "upload")
+	if err != nil {
+		return err
+	}
+
+	link, err := h.uploadService.GetLink(c.Context(), userID, uploadID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, link)
+}
+
+// FileHandler serves files from local storage to holders of a signed URL. Other
+// backends sign URLs that point straight at the object store.
+type FileHandler struct {
+	store *storage.LocalStore
+}
+
+func NewFileHandler(store *storage.LocalStore) *FileHandler {
+	return &FileHandler{
+		store: store,
+	}
+}
+
+// ServeFile handles GET /files/*
+func (h *FileHandler) ServeFile(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return errors.BadRequest("Invalid file path")
+	}
+
+	if err := h.store.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		return errors.Forbidden("Invalid or expired download link")
+	}
+
+	data, err := h.store.Get(c.Context(), key)
+	if err == storage.ErrNotFound {
+		return errors.NotFound("File")
+	}
+	if err != nil {
+		return errors.Internal("Failed to read file")
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Set("Content-Type", contentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(key)))
+	return c.Send(data)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UsageHandler struct {
+	usageMonitorService *services.UsageMonitorService
+}
+
+func NewUsageHandler(usageMonitorService *services.UsageMonitorService) *UsageHandler {
+	return &UsageHandler{
+		usageMonitorService: usageMonitorService,
+	}
+}
+
+// GetUsageFlags handles GET /admin/usage-flags?active=true&limit=, the users and API
+// keys flagged for scraping, newest first
+func (h *UsageHandler) GetUsageFlags(c *fiber.Ctx) error {
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 {
+			return errors.BadRequest("Invalid limit")
+		}
+		limit = n
+	}
+
+	flags, err := h.usageMonitorService.ListFlags(c.Context(), c.Query("active") == "true", limit)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, flags)
+}
+
+// ResolveUsageFlag handles POST /admin/usage-flags/:id/resolve, lifting the flagged
+// subject's stricter limits
+func (h *UsageHandler) ResolveUsageFlag(c *fiber.Ctx) error {
+	adminID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "usage flag")
+	if err != nil {
+		return err
+	}
+
+	if err := h.usageMonitorService.ResolveFlag(c.Context(), adminID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
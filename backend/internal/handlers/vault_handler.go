@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type VaultHandler struct {
+	vaultService *services.VaultService
+}
+
+func NewVaultHandler(vaultService *services.VaultService) *VaultHandler {
+	return &VaultHandler{
+		vaultService: vaultService,
+	}
+}
+
+// GetVaultPositions handles GET /positions/vaults. Wallets are checked against the yield
+// pools listed as ERC-4626 vaults, plus any comma-separated addresses in vaults.
+func (h *VaultHandler) GetVaultPositions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	positions, err := h.vaultService.GetPositions(c.Context(), userID, c.Query("address"), c.Query("vaults"), providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, positions)
+}
+
+// GetVault handles GET /yield/vaults/:chainId/:address for any ERC-4626 vault
+func (h *VaultHandler) GetVault(c *fiber.Ctx) error {
+	chainID, err := supportedChainID(c.Params("chainId"))
+	if err != nil {
+		return err
+	}
+	address, err := normalizeEVMAddress(c.Params("address"))
+	if err != nil {
+		return err
+	}
+
+	vault, err := h.vaultService.GetVault(c.Context(), chainID, address, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, vault)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WalletBackfillHandler struct {
+	backfillService *services.WalletBackfillService
+}
+
+func NewWalletBackfillHandler(backfillService *services.WalletBackfillService) *WalletBackfillHandler {
+	return &WalletBackfillHandler{
+		backfillService: backfillService,
+	}
+}
+
+// GetSyncStatus handles GET /wallets/:walletId/sync-status, reporting how much of the
+// wallet's transaction history has been imported
+func (h *WalletBackfillHandler) GetSyncStatus(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	status, err := h.backfillService.GetSyncStatus(c.Context(), userID, walletID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, status)
+}
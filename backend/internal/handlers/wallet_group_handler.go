@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WalletGroupHandler struct {
+	walletGroupService *services.WalletGroupService
+}
+
+func NewWalletGroupHandler(walletGroupService *services.WalletGroupService) *WalletGroupHandler {
+	return &WalletGroupHandler{
+		walletGroupService: walletGroupService,
+	}
+}
+
+// GetWalletGroups handles GET /wallet-groups
+func (h *WalletGroupHandler) GetWalletGroups(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	groups, err := h.walletGroupService.List(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, groups)
+}
+
+// CreateWalletGroup handles POST /wallet-groups
+func (h *WalletGroupHandler) CreateWalletGroup(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateWalletGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	group, err := h.walletGroupService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), group)
+}
+
+// GetWalletGroup handles GET /wallet-groups/:id
+func (h *WalletGroupHandler) GetWalletGroup(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "wallet group")
+	if err != nil {
+		return err
+	}
+
+	group, err := h.walletGroupService.Get(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, group)
+}
+
+// UpdateWalletGroup handles PUT /wallet-groups/:id
+func (h *WalletGroupHandler) UpdateWalletGroup(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "wallet group")
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateWalletGroupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	group, err := h.walletGroupService.Update(c.Context(), userID, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, group)
+}
+
+// DeleteWalletGroup handles DELETE /wallet-groups/:id
+func (h *WalletGroupHandler) DeleteWalletGroup(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "wallet group")
+	if err != nil {
+		return err
+	}
+
+	if err := h.walletGroupService.Delete(c.Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// GetWalletGroupPortfolio handles GET /wallet-groups/:id/portfolio, combining the
+// balances of the group's wallets
+func (h *WalletGroupHandler) GetWalletGroupPortfolio(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "wallet group")
+	if err != nil {
+		return err
+	}
+
+	hideSmall := c.Query("hideSmall") == "true"
+	includeTestnets, err := includeTestnets(c)
+	if err != nil {
+		return err
+	}
+	keys := providerKeys(c)
+
+	portfolio, err := h.walletGroupService.GetPortfolio(c.Context(), userID, id, hideSmall, includeTestnets, keys.Alchemy, keys.CoinGecko)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, portfolio)
+}
+
+// GetWalletGroupPnL handles GET /wallet-groups/:id/pnl, combining the PnL of the group's
+// wallets. It takes the from, to and method parameters of GET /analytics/pnl/:address.
+func (h *WalletGroupHandler) GetWalletGroupPnL(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "wallet group")
+	if err != nil {
+		return err
+	}
+
+	from := time.Now().AddDate(-1, 0, 0)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			return errors.BadRequest("Invalid from date format. Use YYYY-MM-DD")
+		}
+	}
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			return errors.BadRequest("Invalid to date format. Use YYYY-MM-DD")
+		}
+	}
+
+	var method pnl.CalculationMethod
+	switch c.Query("method", "fifo") {
+	case "fifo":
+		method = pnl.FIFO
+	case "lifo":
+		method = pnl.LIFO
+	default:
+		return errors.BadRequest("Invalid method. Use 'fifo' or 'lifo'")
+	}
+
+	result, err := h.walletGroupService.GetPnL(c.Context(), userID, id, from, to, method)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, result)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WalletHandler struct {
+	walletService *services.WalletService
+}
+
+func NewWalletHandler(walletService *services.WalletService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+	}
+}
+
+// UpdateVisibility handles PUT /wallets/:walletId/visibility. Private wallets are left
+// out of shared links and of views aggregating several users' wallets.
+func (h *WalletHandler) UpdateVisibility(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateWalletVisibilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	wallet, err := h.walletService.SetVisibility(c.Context(), userID, walletID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, wallet)
+}
+
+// RemoveWallet handles DELETE /wallets/:walletId?retention=purge|keep_history. The
+// wallet disappears at once and its data is cleared in the background; the removal
+// returned reports the progress.
+func (h *WalletHandler) RemoveWallet(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	removal, err := h.walletService.Remove(c.Context(), userID, walletID, c.Query("retention"))
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(fiber.StatusAccepted), removal)
+}
+
+// GetWalletRemoval handles GET /wallets/removals/:id
+func (h *WalletHandler) GetWalletRemoval(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	id, err := uuidParam(c, "id", "wallet removal")
+	if err != nil {
+		return err
+	}
+
+	removal, err := h.walletService.GetRemoval(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, removal)
+}
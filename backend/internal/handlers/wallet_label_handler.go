@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WalletLabelHandler struct {
+	labelService *services.WalletLabelService
+}
+
+func NewWalletLabelHandler(labelService *services.WalletLabelService) *WalletLabelHandler {
+	return &WalletLabelHandler{
+		labelService: labelService,
+	}
+}
+
+// GetLabelSuggestions handles GET /wallets/:walletId/label-suggestions, listing the labels
+// the wallet's on-chain history suggests and those the owner accepted
+func (h *WalletLabelHandler) GetLabelSuggestions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	labels, err := h.labelService.GetSuggestions(c.Context(), userID, walletID)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, labels)
+}
+
+// UpdateLabelSuggestion handles PUT /wallets/:walletId/label-suggestions, accepting or
+// dismissing a suggested label
+func (h *WalletLabelHandler) UpdateLabelSuggestion(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateWalletLabelSuggestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	label, err := h.labelService.UpdateSuggestion(c.Context(), userID, walletID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, label)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	stderrors "errors"
+
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/pkg/errors"
@@ -36,7 +38,7 @@ func (h *WatchlistHandler) GetWatchlist(c *fiber.Ctx) error {
 		return errors.Internal("Failed to get watchlist")
 	}
 
-	return c.JSON(watchlists)
+	return respondLegacy(c, watchlists)
 }
 
 // CreateWatchlistItem handles POST /watchlist
@@ -97,7 +99,7 @@ func (h *WatchlistHandler) CreateWatchlistItem(c *fiber.Ctx) error {
 		return errors.Internal("Failed to create watchlist item")
 	}
 
-	return c.Status(201).JSON(watchlist)
+	return respondLegacy(c.Status(201), watchlist)
 }
 
 // DeleteWatchlistItem handles DELETE /watchlist/:id
@@ -121,7 +123,7 @@ func (h *WatchlistHandler) DeleteWatchlistItem(c *fiber.Ctx) error {
 			"watchlistID", watchlistID,
 			"userID", userID,
 		)
-		if err.Error() == "watchlist item not found" {
+		if stderrors.Is(err, repos.ErrWatchlistItemNotFound) {
 			return errors.NotFound("Watchlist item")
 		}
 		return errors.Internal("Failed to delete watchlist item")
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -48,7 +49,12 @@ func createTestWatchlistHandler() (*WatchlistHandler, *MockWatchlistRepository)
 }
 
 func createTestApp(handler *WatchlistHandler) *fiber.App {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return RespondError(c, appErr.Status, appErr)
+		}
+		return fiber.DefaultErrorHandler(c, err)
+	}})
 	
 	// Setup middleware to inject userID for testing
 	app.Use(func(c *fiber.Ctx) error {
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WebhookReportHandler struct {
+	webhookReportService *services.WebhookReportService
+}
+
+func NewWebhookReportHandler(webhookReportService *services.WebhookReportService) *WebhookReportHandler {
+	return &WebhookReportHandler{
+		webhookReportService: webhookReportService,
+	}
+}
+
+// GetWebhookReports handles GET /webhook-reports
+func (h *WebhookReportHandler) GetWebhookReports(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	reports, err := h.webhookReportService.List(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, reports, fiber.Map{
+		"total": len(reports),
+	})
+}
+
+// CreateWebhookReport handles POST /webhook-reports. The response has the secret the
+// report's deliveries are signed with, which isn't shown again.
+func (h *WebhookReportHandler) CreateWebhookReport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.CreateWebhookReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	report, err := h.webhookReportService.Create(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c.Status(201), report)
+}
+
+// GetWebhookReport handles GET /webhook-reports/:id
+func (h *WebhookReportHandler) GetWebhookReport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "webhook report")
+	if err != nil {
+		return err
+	}
+
+	report, err := h.webhookReportService.Get(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, report)
+}
+
+// UpdateWebhookReport handles PUT /webhook-reports/:id
+func (h *WebhookReportHandler) UpdateWebhookReport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "webhook report")
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateWebhookReportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	report, err := h.webhookReportService.Update(c.Context(), userID, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, report)
+}
+
+// DeleteWebhookReport handles DELETE /webhook-reports/:id
+func (h *WebhookReportHandler) DeleteWebhookReport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "webhook report")
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhookReportService.Delete(c.Context(), userID, id); err != nil {
+		return err
+	}
+
+	return c.SendStatus(204)
+}
+
+// TestWebhookReport handles POST /webhook-reports/:id/test, posting the report now
+func (h *WebhookReportHandler) TestWebhookReport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	id, err := uuidParam(c, "id", "webhook report")
+	if err != nil {
+		return err
+	}
+
+	delivery, err := h.webhookReportService.Test(c.Context(), userID, id)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, delivery)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WebhookVerificationHandler struct {
+	verificationService *services.WebhookVerificationService
+}
+
+func NewWebhookVerificationHandler(verificationService *services.WebhookVerificationService) *WebhookVerificationHandler {
+	return &WebhookVerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// VerifyWebhook handles POST /alerts/webhooks/verify. The URL is sent a challenge it
+// must echo in its response; alerts are only delivered to verified URLs.
+func (h *WebhookVerificationHandler) VerifyWebhook(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+
+	var req models.VerifyWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	verification, err := h.verificationService.Verify(c.Context(), userID, req.URL)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, verification)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/internal/workerrpc"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// WorkerTasks queues work on the worker; *workerrpc.Client implements it
+type WorkerTasks interface {
+	SyncWallet(ctx context.Context, userID, walletID uuid.UUID) (*workerrpc.TaskAccepted, error)
+	EvaluateAlert(ctx context.Context, userID, alertID uuid.UUID) (*workerrpc.TaskAccepted, error)
+}
+
+// WorkerTaskHandler lets users run worker jobs for their own wallets and alerts now.
+// Tasks run in the background and report progress as task.progress realtime events.
+type WorkerTaskHandler struct {
+	worker       WorkerTasks
+	walletRepo   repos.WalletRepository
+	alertService services.AlertService
+}
+
+// NewWorkerTaskHandler creates the handler; a nil worker answers every request with 503
+func NewWorkerTaskHandler(worker WorkerTasks, walletRepo repos.WalletRepository, alertService services.AlertService) *WorkerTaskHandler {
+	return &WorkerTaskHandler{
+		worker:       worker,
+		walletRepo:   walletRepo,
+		alertService: alertService,
+	}
+}
+
+// SyncWallet handles POST /wallets/:walletId/sync
+func (h *WorkerTaskHandler) SyncWallet(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	walletID, err := uuidParam(c, "walletId", "wallet")
+	if err != nil {
+		return err
+	}
+
+	wallet, err := h.walletRepo.GetByID(c.Context(), walletID)
+	if err == repos.ErrWalletNotFound || (err == nil && wallet.UserID != userID) {
+		return errors.NotFound("Wallet")
+	}
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+
+	if h.worker == nil {
+		return errWorkerUnavailable
+	}
+	accepted, err := h.worker.SyncWallet(c.Context(), userID, walletID)
+	if err != nil {
+		logger.Error("Failed to queue wallet sync", "walletId", walletID, "error", err)
+		return errors.ExternalServiceError("worker", err)
+	}
+
+	return respondLegacy(c.Status(fiber.StatusAccepted), accepted)
+}
+
+// EvaluateAlert handles POST /alerts/:alertId/evaluate
+func (h *WorkerTaskHandler) EvaluateAlert(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uuid.UUID)
+	if !ok {
+		return errors.Unauthorized("User not authenticated")
+	}
+	alertID, err := uuidParam(c, "alertId", "alert")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.alertService.GetAlert(c.Context(), alertID, userID); err != nil {
+		return errors.NotFound("Alert")
+	}
+
+	if h.worker == nil {
+		return errWorkerUnavailable
+	}
+	accepted, err := h.worker.EvaluateAlert(c.Context(), userID, alertID)
+	if err != nil {
+		logger.Error("Failed to queue alert evaluation", "alertId", alertID, "error", err)
+		return errors.ExternalServiceError("worker", err)
+	}
+
+	return respondLegacy(c.Status(fiber.StatusAccepted), accepted)
+}
+
+var errWorkerUnavailable = errors.New("WORKER_UNAVAILABLE", "On-demand worker tasks are not configured", fiber.StatusServiceUnavailable)
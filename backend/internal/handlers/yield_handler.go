@@ -12,11 +12,13 @@ import (
 
 type YieldHandler struct {
 	yieldService *services.YieldService
+	estimator    *services.YieldEstimator
 }
 
-func NewYieldHandler(yieldService *services.YieldService) *YieldHandler {
+func NewYieldHandler(yieldService *services.YieldService, estimator *services.YieldEstimator) *YieldHandler {
 	return &YieldHandler{
 		yieldService: yieldService,
+		estimator:    estimator,
 	}
 }
 
@@ -54,27 +56,19 @@ func (h *YieldHandler) GetYieldPools(c *fiber.Ctx) error {
 	totalPages := (int(total) + filters.Limit - 1) / filters.Limit
 	page := (filters.Offset / filters.Limit) + 1
 
-	return c.JSON(fiber.Map{
-		"data": pools,
-		"meta": fiber.Map{
-			"page":       page,
-			"limit":      filters.Limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
+	return respondWithMeta(c, pools, fiber.Map{
+		"page":       page,
+		"limit":      filters.Limit,
+		"total":      total,
+		"totalPages": totalPages,
 	})
 }
 
 // GetYieldPositions handles GET /yield/positions/:address
 func (h *YieldHandler) GetYieldPositions(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address parameter is required")
-	}
-
-	// Validate Ethereum address format
-	if !isValidEthereumAddress(address) {
-		return errors.BadRequest("Invalid Ethereum address format")
+	address, err := normalizeEVMAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse query parameters
@@ -91,21 +85,19 @@ func (h *YieldHandler) GetYieldPositions(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(summary)
+	return respondLegacy(c, summary)
 }
 
 // ClaimRewards handles POST /yield/positions/:address/:positionId/claim
 func (h *YieldHandler) ClaimRewards(c *fiber.Ctx) error {
-	address := c.Params("address")
 	positionIDStr := c.Params("positionId")
-
-	if address == "" || positionIDStr == "" {
-		return errors.BadRequest("Address and position ID parameters are required")
+	if positionIDStr == "" {
+		return errors.BadRequest("Position ID parameter is required")
 	}
 
-	// Validate Ethereum address format
-	if !isValidEthereumAddress(address) {
-		return errors.BadRequest("Invalid Ethereum address format")
+	address, err := normalizeEVMAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	// Parse position UUID
@@ -120,7 +112,7 @@ func (h *YieldHandler) ClaimRewards(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(response)
+	return respondLegacy(c, response)
 }
 
 // GetYieldPoolsByProtocol handles GET /yield/pools/protocol/:slug
@@ -143,7 +135,7 @@ func (h *YieldHandler) GetYieldPoolsByProtocol(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"protocol": protocol,
 		"pools":    pools,
 	})
@@ -151,14 +143,9 @@ func (h *YieldHandler) GetYieldPoolsByProtocol(c *fiber.Ctx) error {
 
 // GetYieldPoolsByChain handles GET /yield/pools/chain/:chainId
 func (h *YieldHandler) GetYieldPoolsByChain(c *fiber.Ctx) error {
-	chainIDStr := c.Params("chainId")
-	if chainIDStr == "" {
-		return errors.BadRequest("Chain ID parameter is required")
-	}
-
-	chainID, err := strconv.Atoi(chainIDStr)
+	chainID, err := parseChainID(c.Params("chainId"))
 	if err != nil {
-		return errors.BadRequest("Invalid chain ID format")
+		return err
 	}
 
 	// Get pools for this chain
@@ -167,12 +154,94 @@ func (h *YieldHandler) GetYieldPoolsByChain(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"chainId": chainID,
 		"pools":   pools,
 	})
 }
 
+// SearchYieldPools handles GET /yield/pools/search. Filters are described in
+// services.YieldPoolSearchFromParams.
+func (h *YieldHandler) SearchYieldPools(c *fiber.Ctx) error {
+	search, err := services.YieldPoolSearchFromParams(c.Queries())
+	if err != nil {
+		return err
+	}
+	search.Limit = getIntValueOrDefault(c, "limit", 20)
+	search.Offset = getIntValueOrDefault(c, "offset", 0)
+
+	pools, total, err := h.yieldService.SearchPools(c.Context(), search)
+	if err != nil {
+		return err
+	}
+
+	return respondWithMeta(c, pools, fiber.Map{
+		"total":  total,
+		"limit":  search.Limit,
+		"offset": search.Offset,
+	})
+}
+
+// EstimateYieldPool handles POST /yield/pools/:id/estimate. It quotes the swap or bridge
+// from the given token into the pool and back out, and returns the net APY over each
+// holding period once those legs and gas are paid for.
+func (h *YieldHandler) EstimateYieldPool(c *fiber.Ctx) error {
+	poolID, err := uuidParam(c, "id", "pool")
+	if err != nil {
+		return err
+	}
+
+	var req services.YieldEstimateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	estimate, err := h.estimator.Estimate(c.Context(), poolID, req, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, estimate)
+}
+
+// BuildPositionTransactions handles POST /yield/pools/:id/transactions. It returns the
+// unsigned transactions entering the pool (approve, then deposit) or leaving it (claim,
+// then withdraw) for the owner's wallet to sign in order.
+func (h *YieldHandler) BuildPositionTransactions(c *fiber.Ctx) error {
+	poolID, err := uuidParam(c, "id", "pool")
+	if err != nil {
+		return err
+	}
+
+	var req services.PositionTxsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	txs, err := h.yieldService.BuildPositionTxs(c.Context(), poolID, req, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, txs)
+}
+
+// GetYieldPoolRewards handles GET /yield/pools/:id/rewards, breaking the pool's reward
+// APY down by reward token
+func (h *YieldHandler) GetYieldPoolRewards(c *fiber.Ctx) error {
+	poolID, err := uuidParam(c, "id", "pool")
+	if err != nil {
+		return err
+	}
+
+	rewards, err := h.yieldService.GetPoolRewards(c.Context(), poolID, providerKeys(c).Alchemy)
+	if err != nil {
+		return err
+	}
+
+	return respond(c, rewards)
+}
+
 // GetTopYieldPools handles GET /yield/pools/top
 func (h *YieldHandler) GetTopYieldPools(c *fiber.Ctx) error {
 	limit := getIntValueOrDefault(c, "limit", 10)
@@ -185,7 +254,7 @@ func (h *YieldHandler) GetTopYieldPools(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(fiber.Map{
+	return respondLegacy(c, fiber.Map{
 		"pools": pools,
 	})
 }
@@ -220,27 +289,34 @@ func (h *YieldHandler) GetProtocols(c *fiber.Ctx) error {
 	totalPages := (int(total) + filters.Limit - 1) / filters.Limit
 	page := (filters.Offset / filters.Limit) + 1
 
-	return c.JSON(fiber.Map{
-		"data": protocols,
-		"meta": fiber.Map{
-			"page":       page,
-			"limit":      filters.Limit,
-			"total":      total,
-			"totalPages": totalPages,
-		},
+	return respondWithMeta(c, protocols, fiber.Map{
+		"page":       page,
+		"limit":      filters.Limit,
+		"total":      total,
+		"totalPages": totalPages,
 	})
 }
 
-// CreatePosition handles POST /yield/positions/:address (internal/admin use)
-func (h *YieldHandler) CreatePosition(c *fiber.Ctx) error {
-	address := c.Params("address")
-	if address == "" {
-		return errors.BadRequest("Address parameter is required")
+// GetProtocolTVL handles GET /protocols/:slug/tvl
+func (h *YieldHandler) GetProtocolTVL(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+	if slug == "" {
+		return errors.BadRequest("Protocol slug is required")
 	}
 
-	// Validate Ethereum address format
-	if !isValidEthereumAddress(address) {
-		return errors.BadRequest("Invalid Ethereum address format")
+	tvl, err := h.yieldService.GetProtocolTVL(c.Context(), slug, c.Query("period", "1m"))
+	if err != nil {
+		return err
+	}
+
+	return respondLegacy(c, tvl)
+}
+
+// CreatePosition handles POST /yield/positions/:address (internal/admin use)
+func (h *YieldHandler) CreatePosition(c *fiber.Ctx) error {
+	address, err := normalizeEVMAddress(c.Params("address"))
+	if err != nil {
+		return err
 	}
 
 	var req services.CreatePositionRequest
@@ -254,7 +330,7 @@ func (h *YieldHandler) CreatePosition(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.Status(201).JSON(position)
+	return respondLegacy(c.Status(201), position)
 }
 
 // UpdatePosition handles PUT /yield/positions/:positionId (internal/admin use)
@@ -281,7 +357,7 @@ func (h *YieldHandler) UpdatePosition(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(position)
+	return respondLegacy(c, position)
 }
 
 // Helper functions
@@ -348,15 +424,3 @@ func getBoolValueOrDefault(c *fiber.Ctx, key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
-
-func isValidEthereumAddress(address string) bool {
-	// Basic validation for Ethereum address format
-	if len(address) != 42 {
-		return false
-	}
-	if address[:2] != "0x" {
-		return false
-	}
-	// Additional validation could be added here
-	return true
-}
\ No newline at end of file
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type YieldPoolMigrationHandler struct {
+	migrationService *services.YieldPoolMigrationService
+}
+
+func NewYieldPoolMigrationHandler(migrationService *services.YieldPoolMigrationService) *YieldPoolMigrationHandler {
+	return &YieldPoolMigrationHandler{
+		migrationService: migrationService,
+	}
+}
+
+// GetYieldPool handles GET /yield/pools/:id, including the pool a deprecated pool
+// migrated to
+func (h *YieldPoolMigrationHandler) GetYieldPool(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id", "pool")
+	if err != nil {
+		return err
+	}
+
+	pool, err := h.migrationService.GetPool(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return respond(c, pool)
+}
+
+// SetSuccessor handles PUT /admin/yield-pools/:poolId/successor, deprecating a pool in
+// favour of the pool the protocol migrated it to
+func (h *YieldPoolMigrationHandler) SetSuccessor(c *fiber.Ctx) error {
+	var req models.SetYieldPoolSuccessorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.BadRequest("Invalid request body")
+	}
+
+	pool, err := h.migrationService.SetSuccessor(c.Context(), c.Params("poolId"), &req)
+	if err != nil {
+		return err
+	}
+	return respond(c, pool)
+}
+
+// ClearSuccessor handles DELETE /admin/yield-pools/:poolId/successor
+func (h *YieldPoolMigrationHandler) ClearSuccessor(c *fiber.Ctx) error {
+	if err := h.migrationService.ClearSuccessor(c.Context(), c.Params("poolId")); err != nil {
+		return err
+	}
+	return c.SendStatus(204)
+}
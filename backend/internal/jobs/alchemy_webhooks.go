@@ -0,0 +1,239 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	alchemyWebhookBatchSize = 100
+	// maxAlchemyWebhookAttempts is how many times an event may fail before it's dropped
+	maxAlchemyWebhookAttempts = 5
+	// AlchemyWebhookEventRetention is how long processed and failed events are kept
+	AlchemyWebhookEventRetention = 7 * 24 * time.Hour
+)
+
+// webhookTransactionFetcher reads a wallet's transactions in a block range and prices
+// their gas; *blockchain.BlockchainService in production
+type webhookTransactionFetcher interface {
+	GetTransactionsInRange(ctx context.Context, address string, chainID int, blocks blockchain.BlockRange) ([]*models.Transaction, error)
+	EnrichGasFees(ctx context.Context, transactions []*models.Transaction)
+}
+
+// addressAlertEvaluator evaluates the alerts on addresses; *AlertEvaluatorJob in production
+type addressAlertEvaluator interface {
+	EvaluateAddresses(ctx context.Context, addresses []string) (int, error)
+}
+
+// webhookAddressUpdater changes the addresses a webhook watches;
+// *blockchain.AlchemyNotifyClient in production
+type webhookAddressUpdater interface {
+	UpdateWebhookAddresses(ctx context.Context, webhookID string, added, removed []string) error
+}
+
+// AlchemyWebhookJob ingests the activity Alchemy Notify webhooks report, so wallets and
+// their alerts catch up within seconds rather than at the next scheduled sync. For each
+// queued event it imports the transactions of the blocks involved for every wallet
+// tracking one of the addresses, then evaluates the alerts on those addresses. It also
+// keeps each webhook watching exactly the EVM wallets on its chain as wallets are added
+// and removed.
+type AlchemyWebhookJob struct {
+	webhookRepo  repos.AlchemyWebhookRepository
+	walletRepo   repos.WalletRepository
+	backfillRepo repos.WalletBackfillRepository
+	transactions webhookTransactionFetcher
+	alerts       addressAlertEvaluator
+	notify       webhookAddressUpdater
+	webhooks     []blockchain.AlchemyWebhook
+	clock        clock.Clock
+}
+
+// NewAlchemyWebhookJob creates the job. Without a notify client the webhooks' addresses
+// are left as set in the Alchemy dashboard; without alerts, none are evaluated.
+func NewAlchemyWebhookJob(webhookRepo repos.AlchemyWebhookRepository, walletRepo repos.WalletRepository, backfillRepo repos.WalletBackfillRepository, blockchainService *blockchain.BlockchainService, alerts *AlertEvaluatorJob, notify *blockchain.AlchemyNotifyClient, webhooks []blockchain.AlchemyWebhook) *AlchemyWebhookJob {
+	j := &AlchemyWebhookJob{
+		webhookRepo:  webhookRepo,
+		walletRepo:   walletRepo,
+		backfillRepo: backfillRepo,
+		transactions: blockchainService,
+		webhooks:     webhooks,
+		clock:        clock.System,
+	}
+	// Nil pointers are left out so the interfaces stay nil
+	if alerts != nil {
+		j.alerts = alerts
+	}
+	if notify != nil {
+		j.notify = notify
+	}
+	return j
+}
+
+// Run processes the queued events, oldest first. A failed event is retried next run
+// until it has failed too often; when the provider throttles, the run ends there and
+// the event is retried without counting against it.
+func (j *AlchemyWebhookJob) Run(ctx context.Context) error {
+	events, err := j.webhookRepo.GetPending(ctx, alchemyWebhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i, event := range events {
+		err := j.process(ctx, event)
+		if errors.Is(err, blockchain.ErrRateLimited) {
+			logger.Warn("Alchemy webhook processing rate limited, resuming next run", "eventId", event.EventID)
+			QuotaExhausted(ctx, err, fmt.Sprintf("%d webhook events", len(events)-i))
+			return nil
+		}
+		if err != nil {
+			logger.Warn("Failed to process Alchemy webhook event", "eventId", event.EventID, "error", err)
+			if err := j.webhookRepo.RecordError(ctx, event.ID, err.Error(), maxAlchemyWebhookAttempts); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := j.webhookRepo.MarkProcessed(ctx, event.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process imports the event's transactions for the wallets tracking its addresses and
+// evaluates their alerts. Addresses no longer tracked by anyone are ignored.
+func (j *AlchemyWebhookJob) process(ctx context.Context, event *models.AlchemyWebhookEvent) error {
+	wallets, err := j.walletRepo.GetAllByAddresses(ctx, event.Addresses, event.ChainID)
+	if err != nil {
+		return err
+	}
+	if len(wallets) == 0 {
+		return nil
+	}
+
+	// Users tracking the same address share one fetch
+	fetched := make(map[string][]*models.Transaction)
+	var addresses []string
+	imported := 0
+	for _, wallet := range wallets {
+		address := addr.Normalize(wallet.Address)
+		transactions, ok := fetched[address]
+		if !ok {
+			addresses = append(addresses, address)
+			if event.FromBlock != nil && event.ToBlock != nil {
+				blocks := blockchain.BlockRange{From: *event.FromBlock, To: *event.ToBlock}
+				transactions, err = j.transactions.GetTransactionsInRange(ctx, wallet.Address, event.ChainID, blocks)
+				if err != nil {
+					return fmt.Errorf("failed to fetch transactions of %s: %w", address, err)
+				}
+				for _, tx := range transactions {
+					normalizeBackfilledTransaction(tx, wallet.Address)
+				}
+				j.transactions.EnrichGasFees(ctx, transactions)
+			}
+			fetched[address] = transactions
+		}
+		if len(transactions) == 0 {
+			continue
+		}
+		linked, err := j.backfillRepo.SaveTransactions(ctx, wallet, transactions)
+		if err != nil {
+			return err
+		}
+		imported += linked
+	}
+
+	triggered := 0
+	if j.alerts != nil {
+		if triggered, err = j.alerts.EvaluateAddresses(ctx, addresses); err != nil {
+			return err
+		}
+	}
+	logger.Info("Processed Alchemy webhook event",
+		"eventId", event.EventID,
+		"chainId", event.ChainID,
+		"wallets", len(wallets),
+		"imported", imported,
+		"triggered", triggered)
+	return nil
+}
+
+// SyncSubscriptions adds the addresses of new EVM wallets to their chain's webhook and
+// removes those no wallet tracks anymore, then prunes old events. A webhook that fails
+// to update is retried next run.
+func (j *AlchemyWebhookJob) SyncSubscriptions(ctx context.Context) error {
+	if j.notify != nil {
+		for _, webhook := range j.webhooks {
+			if err := j.syncWebhook(ctx, webhook); err != nil {
+				logger.Warn("Failed to update Alchemy webhook addresses", "webhookId", webhook.ID, "error", err)
+			}
+		}
+	}
+
+	pruned, err := j.webhookRepo.DeleteBefore(ctx, j.clock.Now().Add(-AlchemyWebhookEventRetention))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		logger.Info("Pruned Alchemy webhook events", "pruned", pruned)
+	}
+	return nil
+}
+
+func (j *AlchemyWebhookJob) syncWebhook(ctx context.Context, webhook blockchain.AlchemyWebhook) error {
+	watched, err := j.webhookRepo.GetWatchedAddresses(ctx, webhook.ChainID)
+	if err != nil {
+		return err
+	}
+	subscribed, err := j.webhookRepo.GetSubscribedAddresses(ctx, webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	added, removed := diffAddresses(watched, subscribed)
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	if err := j.notify.UpdateWebhookAddresses(ctx, webhook.ID, added, removed); err != nil {
+		return err
+	}
+	if err := j.webhookRepo.SetSubscribed(ctx, webhook.ID, webhook.ChainID, added, removed); err != nil {
+		return err
+	}
+	logger.Info("Updated Alchemy webhook addresses",
+		"webhookId", webhook.ID,
+		"chainId", webhook.ChainID,
+		"added", len(added),
+		"removed", len(removed))
+	return nil
+}
+
+// diffAddresses returns the addresses wanted but not subscribed, and those subscribed
+// but no longer wanted
+func diffAddresses(wanted, subscribed []string) (added, removed []string) {
+	have := make(map[string]bool, len(subscribed))
+	for _, address := range subscribed {
+		have[address] = true
+	}
+	want := make(map[string]bool, len(wanted))
+	for _, address := range wanted {
+		want[address] = true
+		if !have[address] {
+			added = append(added, address)
+		}
+	}
+	for _, address := range subscribed {
+		if !want[address] {
+			removed = append(removed, address)
+		}
+	}
+	return added, removed
+}
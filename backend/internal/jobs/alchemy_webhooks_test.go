@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAlchemyWebhookRepo struct {
+	repos.AlchemyWebhookRepository
+	pending    []*models.AlchemyWebhookEvent
+	processed  []uuid.UUID
+	errors     map[uuid.UUID]int
+	watched    map[int][]string
+	subscribed map[string][]string
+}
+
+func (r *memoryAlchemyWebhookRepo) GetPending(ctx context.Context, limit int) ([]*models.AlchemyWebhookEvent, error) {
+	return r.pending, nil
+}
+
+func (r *memoryAlchemyWebhookRepo) MarkProcessed(ctx context.Context, id uuid.UUID) error {
+	r.processed = append(r.processed, id)
+	return nil
+}
+
+func (r *memoryAlchemyWebhookRepo) RecordError(ctx context.Context, id uuid.UUID, message string, maxAttempts int) error {
+	r.errors[id]++
+	return nil
+}
+
+func (r *memoryAlchemyWebhookRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryAlchemyWebhookRepo) GetWatchedAddresses(ctx context.Context, chainID int) ([]string, error) {
+	return r.watched[chainID], nil
+}
+
+func (r *memoryAlchemyWebhookRepo) GetSubscribedAddresses(ctx context.Context, webhookID string) ([]string, error) {
+	return r.subscribed[webhookID], nil
+}
+
+func (r *memoryAlchemyWebhookRepo) SetSubscribed(ctx context.Context, webhookID string, chainID int, added, removed []string) error {
+	current, _ := diffAddresses(r.subscribed[webhookID], removed)
+	r.subscribed[webhookID] = append(current, added...)
+	return nil
+}
+
+type memoryWebhookWalletRepo struct {
+	repos.WalletRepository
+	wallets []*models.Wallet
+}
+
+func (r *memoryWebhookWalletRepo) GetAllByAddresses(ctx context.Context, addresses []string, chainID int) ([]*models.Wallet, error) {
+	var found []*models.Wallet
+	for _, wallet := range r.wallets {
+		for _, address := range addresses {
+			if wallet.Address == address && wallet.ChainID == chainID {
+				found = append(found, wallet)
+			}
+		}
+	}
+	return found, nil
+}
+
+type memoryWebhookBackfillRepo struct {
+	repos.WalletBackfillRepository
+	saved map[uuid.UUID][]*models.Transaction
+}
+
+func (r *memoryWebhookBackfillRepo) SaveTransactions(ctx context.Context, wallet *models.Wallet, transactions []*models.Transaction) (int, error) {
+	r.saved[wallet.ID] = append(r.saved[wallet.ID], transactions...)
+	return len(transactions), nil
+}
+
+type fakeTransactionFetcher struct {
+	calls int
+	err   error
+}
+
+func (f *fakeTransactionFetcher) GetTransactionsInRange(ctx context.Context, address string, chainID int, blocks blockchain.BlockRange) ([]*models.Transaction, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	value := "0xde0b6b3a7640000"
+	return []*models.Transaction{{
+		Hash:        fmt.Sprintf("0x%x", blocks.From),
+		ChainID:     chainID,
+		FromAddress: "0x59479de9d374bdbcba6c791e5d036591976fe425",
+		ToAddress:   &address,
+		Value:       &value,
+		Type:        "external",
+	}}, nil
+}
+
+// EnrichGasFees prices every transaction's gas at a dollar
+func (f *fakeTransactionFetcher) EnrichGasFees(ctx context.Context, transactions []*models.Transaction) {
+	for _, tx := range transactions {
+		fee := 1.0
+		tx.GasFeeUSD = &fee
+	}
+}
+
+type recordingAddressAlerts struct {
+	addresses [][]string
+}
+
+func (a *recordingAddressAlerts) EvaluateAddresses(ctx context.Context, addresses []string) (int, error) {
+	a.addresses = append(a.addresses, addresses)
+	return 0, nil
+}
+
+type recordingWebhookUpdater struct {
+	added, removed map[string][]string
+	err            error
+}
+
+func (u *recordingWebhookUpdater) UpdateWebhookAddresses(ctx context.Context, webhookID string, added, removed []string) error {
+	if u.err != nil {
+		return u.err
+	}
+	u.added[webhookID] = append(u.added[webhookID], added...)
+	u.removed[webhookID] = append(u.removed[webhookID], removed...)
+	return nil
+}
+
+func TestAlchemyWebhookJobRun(t *testing.T) {
+	const address = "0x8ba1f109551bd432803012645ac136ddd64dba72"
+	alice := &models.Wallet{ID: uuid.New(), UserID: uuid.New(), Address: address, ChainID: 1}
+	bob := &models.Wallet{ID: uuid.New(), UserID: uuid.New(), Address: address, ChainID: 1}
+	block := int64(100)
+	event := &models.AlchemyWebhookEvent{
+		ID:        uuid.New(),
+		EventID:   "whevt_1",
+		ChainID:   1,
+		Addresses: []string{address, "0x59479de9d374bdbcba6c791e5d036591976fe425"},
+		FromBlock: &block,
+		ToBlock:   &block,
+	}
+	untracked := &models.AlchemyWebhookEvent{ID: uuid.New(), EventID: "whevt_2", ChainID: 1, Addresses: []string{"0xother"}}
+
+	webhookRepo := &memoryAlchemyWebhookRepo{pending: []*models.AlchemyWebhookEvent{event, untracked}, errors: map[uuid.UUID]int{}}
+	backfillRepo := &memoryWebhookBackfillRepo{saved: map[uuid.UUID][]*models.Transaction{}}
+	fetcher := &fakeTransactionFetcher{}
+	alerts := &recordingAddressAlerts{}
+	job := &AlchemyWebhookJob{
+		webhookRepo:  webhookRepo,
+		walletRepo:   &memoryWebhookWalletRepo{wallets: []*models.Wallet{alice, bob}},
+		backfillRepo: backfillRepo,
+		transactions: fetcher,
+		alerts:       alerts,
+	}
+
+	require.NoError(t, job.Run(context.Background()))
+
+	// Both users' wallets get the transaction, fetched once and normalized as a receive
+	assert.Equal(t, 1, fetcher.calls)
+	require.Len(t, backfillRepo.saved[alice.ID], 1)
+	require.Len(t, backfillRepo.saved[bob.ID], 1)
+	tx := backfillRepo.saved[alice.ID][0]
+	assert.Equal(t, "receive", tx.Type)
+	assert.Equal(t, models.TransactionStatusConfirmed, tx.Status)
+	assert.Equal(t, "1000000000000000000", *tx.Value)
+	require.NotNil(t, tx.GasFeeUSD, "gas is priced before the transaction is stored")
+	assert.Equal(t, 1.0, *tx.GasFeeUSD)
+
+	// Alerts are evaluated on the tracked address only
+	assert.Equal(t, [][]string{{address}}, alerts.addresses)
+	assert.Equal(t, []uuid.UUID{event.ID, untracked.ID}, webhookRepo.processed)
+}
+
+func TestAlchemyWebhookJobRunFailures(t *testing.T) {
+	const address = "0x8ba1f109551bd432803012645ac136ddd64dba72"
+	wallet := &models.Wallet{ID: uuid.New(), Address: address, ChainID: 1}
+	block := int64(100)
+	event := &models.AlchemyWebhookEvent{ID: uuid.New(), ChainID: 1, Addresses: []string{address}, FromBlock: &block, ToBlock: &block}
+
+	newJob := func(err error) (*AlchemyWebhookJob, *memoryAlchemyWebhookRepo) {
+		webhookRepo := &memoryAlchemyWebhookRepo{pending: []*models.AlchemyWebhookEvent{event}, errors: map[uuid.UUID]int{}}
+		return &AlchemyWebhookJob{
+			webhookRepo:  webhookRepo,
+			walletRepo:   &memoryWebhookWalletRepo{wallets: []*models.Wallet{wallet}},
+			backfillRepo: &memoryWebhookBackfillRepo{saved: map[uuid.UUID][]*models.Transaction{}},
+			transactions: &fakeTransactionFetcher{err: err},
+		}, webhookRepo
+	}
+
+	// A failed fetch counts against the event
+	job, webhookRepo := newJob(fmt.Errorf("boom"))
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, 1, webhookRepo.errors[event.ID])
+	assert.Empty(t, webhookRepo.processed)
+
+	// Throttling leaves it pending without counting
+	job, webhookRepo = newJob(fmt.Errorf("alchemy API error: %w", blockchain.ErrRateLimited))
+	require.NoError(t, job.Run(context.Background()))
+	assert.Empty(t, webhookRepo.errors)
+	assert.Empty(t, webhookRepo.processed)
+}
+
+func TestAlchemyWebhookJobSyncSubscriptions(t *testing.T) {
+	webhookRepo := &memoryAlchemyWebhookRepo{
+		watched: map[int][]string{
+			1:   {"0xa", "0xb"},
+			137: {"0xc"},
+		},
+		subscribed: map[string][]string{
+			"wh_eth":     {"0xb", "0xgone"},
+			"wh_polygon": {"0xc"},
+		},
+	}
+	updater := &recordingWebhookUpdater{added: map[string][]string{}, removed: map[string][]string{}}
+	job := &AlchemyWebhookJob{
+		webhookRepo: webhookRepo,
+		notify:      updater,
+		webhooks: []blockchain.AlchemyWebhook{
+			{ID: "wh_eth", ChainID: 1},
+			{ID: "wh_polygon", ChainID: 137},
+		},
+		clock: clock.System,
+	}
+
+	require.NoError(t, job.SyncSubscriptions(context.Background()))
+	assert.Equal(t, map[string][]string{"wh_eth": {"0xa"}}, updater.added)
+	assert.Equal(t, map[string][]string{"wh_eth": {"0xgone"}}, updater.removed)
+	assert.ElementsMatch(t, []string{"0xa", "0xb"}, webhookRepo.subscribed["wh_eth"])
+
+	// A failed update isn't recorded, so it's retried next run
+	updater.err = fmt.Errorf("unavailable")
+	webhookRepo.watched[137] = []string{"0xc", "0xd"}
+	require.NoError(t, job.SyncSubscriptions(context.Background()))
+	assert.Equal(t, []string{"0xc"}, webhookRepo.subscribed["wh_polygon"])
+}
+
+func TestDiffAddresses(t *testing.T) {
+	added, removed := diffAddresses([]string{"0xa", "0xb", "0xc"}, []string{"0xb", "0xd"})
+	assert.Equal(t, []string{"0xa", "0xc"}, added)
+	assert.Equal(t, []string{"0xd"}, removed)
+
+	added, removed = diffAddresses(nil, nil)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
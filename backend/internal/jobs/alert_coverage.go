@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// coverageRun collects the checks of one evaluation run, so alert detail can show when
+// each alert was actually looked at. Evaluators reach it through the context; all its
+// methods are no-ops on a nil run.
+type coverageRun struct {
+	mu     sync.Mutex
+	now    time.Time
+	checks map[uuid.UUID]*models.AlertCheck
+	// staleSkips counts the alerts not evaluated because their data was too old
+	staleSkips int
+}
+
+type coverageRunKey struct{}
+
+func newCoverageRun(now time.Time) *coverageRun {
+	return &coverageRun{now: now, checks: make(map[uuid.UUID]*models.AlertCheck)}
+}
+
+func withCoverageRun(ctx context.Context, run *coverageRun) context.Context {
+	return context.WithValue(ctx, coverageRunKey{}, run)
+}
+
+// coverageRunFrom returns the run the context is evaluating for, or nil
+func coverageRunFrom(ctx context.Context) *coverageRun {
+	run, _ := ctx.Value(coverageRunKey{}).(*coverageRun)
+	return run
+}
+
+// check returns the alert's check, adding it if it's the first mention. Callers hold mu.
+func (r *coverageRun) check(alertID uuid.UUID) *models.AlertCheck {
+	c, ok := r.checks[alertID]
+	if !ok {
+		c = &models.AlertCheck{AlertID: alertID, CheckedAt: r.now}
+		r.checks[alertID] = c
+	}
+	return c
+}
+
+// checked records that the alerts were evaluated, failing them all when failed
+func (r *coverageRun) checked(alerts []models.Alert, failed bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range alerts {
+		c := r.check(alerts[i].ID)
+		c.Failed = c.Failed || failed
+	}
+}
+
+// failed records that one alert couldn't be evaluated
+func (r *coverageRun) failed(alertID uuid.UUID) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.check(alertID).Failed = true
+}
+
+// data records when the data the alerts were evaluated on was from, if known, and
+// whether it was too old to trust
+func (r *coverageRun) data(alerts []models.Alert, at *time.Time, stale bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range alerts {
+		c := r.check(alerts[i].ID)
+		c.Stale = c.Stale || stale
+		if at != nil && (c.DataAt == nil || at.After(*c.DataAt)) {
+			dataAt := *at
+			c.DataAt = &dataAt
+		}
+	}
+}
+
+// skippedStale records that the alerts weren't evaluated because their data was too old
+// to trust
+func (r *coverageRun) skippedStale(alerts []models.Alert) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range alerts {
+		r.check(alerts[i].ID).Stale = true
+	}
+	r.staleSkips += len(alerts)
+}
+
+// skipped returns how many alerts were skipped for stale data
+func (r *coverageRun) skipped() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.staleSkips
+}
+
+func (r *coverageRun) list() []models.AlertCheck {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checks := make([]models.AlertCheck, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, *c)
+	}
+	return checks
+}
+
+// recordCoverage stores a run's checks and prunes buckets past retention. Failures are
+// logged rather than returned so coverage never holds up alerting.
+func (j *AlertEvaluatorJob) recordCoverage(ctx context.Context, run *coverageRun) {
+	if j.coverageRepo == nil {
+		return
+	}
+	if err := j.coverageRepo.RecordChecks(ctx, run.list()); err != nil {
+		logger.Warn("Failed to record alert coverage", "error", err)
+		return
+	}
+	if _, err := j.coverageRepo.DeleteBefore(ctx, run.now.Add(-services.AlertCoverageRetention)); err != nil {
+		logger.Warn("Failed to prune alert coverage", "error", err)
+	}
+}
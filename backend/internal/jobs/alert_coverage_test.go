@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverageRun(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	run := newCoverageRun(now)
+	ctx := withCoverageRun(context.Background(), run)
+
+	fresh, stale, failed := models.Alert{ID: uuid.New()}, models.Alert{ID: uuid.New()}, models.Alert{ID: uuid.New()}
+	older, newer := now.Add(-time.Hour), now.Add(-time.Minute)
+
+	coverageRunFrom(ctx).data([]models.Alert{fresh}, &newer, false)
+	coverageRunFrom(ctx).data([]models.Alert{stale}, &older, true)
+	coverageRunFrom(ctx).data([]models.Alert{stale}, &newer, false)
+	coverageRunFrom(ctx).failed(failed.ID)
+	run.checked([]models.Alert{fresh, stale, failed}, false)
+
+	checks := make(map[uuid.UUID]models.AlertCheck)
+	for _, c := range run.list() {
+		checks[c.AlertID] = c
+	}
+	require.Len(t, checks, 3)
+
+	assert.False(t, checks[fresh.ID].Stale)
+	assert.Equal(t, &newer, checks[fresh.ID].DataAt)
+	assert.True(t, checks[stale.ID].Stale, "any stale data makes the check stale")
+	assert.Equal(t, &newer, checks[stale.ID].DataAt)
+	assert.True(t, checks[failed.ID].Failed)
+	assert.Equal(t, now, checks[failed.ID].CheckedAt)
+
+	// Evaluators outside a run have nowhere to record to
+	coverageRunFrom(context.Background()).failed(fresh.ID)
+	assert.Zero(t, coverageRunFrom(context.Background()).skipped())
+}
+
+func TestCoverageRunSkippedStale(t *testing.T) {
+	run := newCoverageRun(time.Now())
+	skipped := []models.Alert{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	run.skippedStale(skipped)
+	run.skippedStale(nil)
+	assert.Equal(t, 2, run.skipped())
+	for _, c := range run.list() {
+		assert.True(t, c.Stale)
+		assert.False(t, c.Failed, "skipping isn't failing")
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+)
+
+// builtinEvaluator adapts one of the job's batch evaluation methods to services.AlertEvaluator
+type builtinEvaluator struct {
+	alertType string
+	batch     func(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error)
+}
+
+func (e *builtinEvaluator) Type() string {
+	return e.alertType
+}
+
+func (e *builtinEvaluator) Validate(conditions models.AlertConditions) error {
+	return services.ValidateBuiltinAlertConditions(e.alertType, conditions)
+}
+
+// Evaluate runs the batch evaluation on its own, capturing the trigger instead of firing it
+func (e *builtinEvaluator) Evaluate(ctx context.Context, alert *models.Alert) (bool, map[string]interface{}, error) {
+	var payload map[string]interface{}
+	_, err := e.batch(ctx, []models.Alert{*alert}, func(_ context.Context, _ *models.Alert, p map[string]interface{}) error {
+		payload = p
+		return nil
+	})
+	return payload != nil, payload, err
+}
+
+func (e *builtinEvaluator) EvaluateBatch(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	return e.batch(ctx, alerts, trigger)
+}
+
+// builtinEvaluators returns the evaluators for the alert types the job ships with
+func (j *AlertEvaluatorJob) builtinEvaluators() *services.AlertEvaluatorRegistry {
+	registry := services.NewAlertEvaluatorRegistry()
+	for alertType, batch := range map[string]func(context.Context, []models.Alert, services.AlertTrigger) (int, error){
+		AlertTypePriceAbove:      j.evaluatePriceAlerts,
+		AlertTypePriceBelow:      j.evaluatePriceAlerts,
+		AlertTypeLargeTransfer:   j.evaluateTransferAlerts,
+		AlertTypeApproval:        j.evaluateApprovalAlerts,
+		AlertTypeLiquidityChange: j.evaluateLiquidityAlerts,
+		AlertTypeAPRChange:       j.evaluateAPRAlerts,
+		AlertTypeComposite:       j.evaluateCompositeAlerts,
+		// Evaluated every minute with escalation by LiquidationMonitorJob
+		AlertTypeHealthFactor: func(context.Context, []models.Alert, services.AlertTrigger) (int, error) {
+			return 0, nil
+		},
+		AlertTypePortfolioValue:  j.evaluatePortfolioValueAlerts,
+		AlertTypePositionAPYDrop: j.evaluatePositionAPYAlerts,
+		AlertTypeILThreshold:     j.evaluateILAlerts,
+		AlertTypeNewMatch:        j.evaluateNewMatchAlerts,
+		AlertTypeUnlockReminder:  j.evaluateUnlockReminders,
+		AlertTypeBalanceChange:   j.evaluateBalanceChangeAlerts,
+		AlertTypeBudgetExceeded:  j.evaluateBudgetAlerts,
+	} {
+		// Types are distinct map keys, so registration can't collide
+		_ = registry.Register(&builtinEvaluator{alertType: alertType, batch: batch})
+	}
+	return registry
+}
+
+// trigger fires an alert through the alert service
+func (j *AlertEvaluatorJob) trigger(ctx context.Context, alert *models.Alert, payload map[string]interface{}) error {
+	if err := j.alertService.TriggerAlert(ctx, alert.ID, payload); err != nil {
+		return err
+	}
+	if j.analytics != nil {
+		j.analytics.Track(alert.UserID, models.AnalyticsAlertTriggered, map[string]interface{}{"alert_type": alert.Type})
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// alertPriceMaxAge is how old a stored token price may be before the evaluator
+// asks the external price client instead
+const alertPriceMaxAge = 15 * time.Minute
+
+// alertDataMaxAge is how old a price may be, after trying to refresh it, before alerts
+// reading it are skipped rather than evaluated on stale data
+const alertDataMaxAge = 30 * time.Minute
+
+// errStaleData is returned for data too old to evaluate an alert on
+var errStaleData = errors.New("data is too old to evaluate on")
+
+// tokenKey identifies a token across chains; addresses are lowercased
+type tokenKey struct {
+	Address string
+	ChainID int
+}
+
+func newTokenKey(address string, chainID int) tokenKey {
+	return tokenKey{Address: strings.ToLower(address), ChainID: chainID}
+}
+
+// alertPriceClient fetches live prices for stale ones; *external.CoinGeckoClient in production
+type alertPriceClient interface {
+	GetTokenPrices(ctx context.Context, tokenIDs []string) (external.PriceResponse, error)
+	GetTokenPricesByContract(ctx context.Context, chainID int, addresses []string) (external.PriceResponse, error)
+}
+
+// getTokenPrices loads prices for all tokens in a single query, then refreshes
+// missing or stale prices from CoinGecko, looked up by chain and address. Tokens without
+// a tokens row are refreshed like any other. The age of each price is noted against the
+// alerts in tokenMap for coverage; prices that are still missing or stale afterwards
+// mark their alerts' checks stale. Prices older than the job's maxDataAge are left out
+// and reported in the returned set instead, for their alerts to be skipped.
+func (j *AlertEvaluatorJob) getTokenPrices(ctx context.Context, tokenMap map[tokenKey][]models.Alert) (map[tokenKey]float64, map[tokenKey]bool, error) {
+	prices := make(map[tokenKey]float64, len(tokenMap))
+	tooOld := make(map[tokenKey]bool)
+	if len(tokenMap) == 0 {
+		return prices, tooOld, nil
+	}
+
+	// Keys are lowercased as tokens are stored, so the (address, chain_id) index is used
+	addresses := make([]string, 0, len(tokenMap))
+	chainIDs := make([]int32, 0, len(tokenMap))
+	for key := range tokenMap {
+		addresses = append(addresses, key.Address)
+		chainIDs = append(chainIDs, int32(key.ChainID))
+	}
+
+	rows, err := j.db.Query(ctx, `
+		SELECT k.address, k.chain_id, p.price_usd, p.updated_at
+		FROM UNNEST($1::text[], $2::int[]) AS k(address, chain_id)
+		LEFT JOIN tokens t ON t.address = k.address AND t.chain_id = k.chain_id
+		LEFT JOIN token_prices_latest p ON p.token_id = t.id`,
+		addresses, chainIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query token prices: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []tokenKey
+	now := j.clock.Now()
+	cutoff := now.Add(-alertPriceMaxAge)
+	updated := make(map[tokenKey]*time.Time, len(tokenMap))
+
+	for rows.Next() {
+		var key tokenKey
+		var price *float64
+		var lastUpdated *time.Time
+		if err := rows.Scan(&key.Address, &key.ChainID, &price, &lastUpdated); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan token price: %w", err)
+		}
+
+		if price != nil {
+			prices[key] = *price
+			updated[key] = lastUpdated
+		}
+		if price == nil || lastUpdated == nil || lastUpdated.Before(cutoff) {
+			stale = append(stale, key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read token prices: %w", err)
+	}
+
+	if len(stale) > 0 && j.priceClient != nil {
+		for _, key := range j.refreshStalePrices(ctx, stale, prices) {
+			updated[key] = &now
+		}
+	}
+
+	coverage := coverageRunFrom(ctx)
+	for key, alerts := range tokenMap {
+		at := updated[key]
+		coverage.data(alerts, at, at == nil || at.Before(cutoff))
+		if _, ok := prices[key]; ok && (at == nil || now.Sub(*at) > j.maxDataAge) {
+			delete(prices, key)
+			tooOld[key] = true
+		}
+	}
+
+	return prices, tooOld, nil
+}
+
+// refreshStalePrices overwrites stale entries with live prices, returning the tokens it
+// refreshed. Native tokens are priced by their chain's gas token and contracts by chain
+// and address, never by symbol, so a token can't borrow another's price by copying its
+// symbol. Failures are logged and the stored price, if any, is kept.
+func (j *AlertEvaluatorJob) refreshStalePrices(ctx context.Context, stale []tokenKey, prices map[tokenKey]float64) []tokenKey {
+	natives := make(map[string][]tokenKey) // CoinGecko ID -> native tokens
+	contracts := make(map[int][]string)    // chain ID -> contract addresses
+	for _, key := range stale {
+		if key.Address == blockchain.NativeTokenAddress {
+			id := blockchain.NativeTokenCoinGeckoID(key.ChainID)
+			natives[id] = append(natives[id], key)
+			continue
+		}
+		contracts[key.ChainID] = append(contracts[key.ChainID], key.Address)
+	}
+
+	var refreshed []tokenKey
+	if len(natives) > 0 {
+		ids := make([]string, 0, len(natives))
+		for id := range natives {
+			ids = append(ids, id)
+		}
+		live, err := j.priceClient.GetTokenPrices(ctx, ids)
+		if err != nil {
+			logger.Warn("Failed to refresh stale native token prices, using stored prices", "tokens", len(ids), "error", err)
+		}
+		for id, keys := range natives {
+			p, ok := live[id]
+			if !ok {
+				continue
+			}
+			for _, key := range keys {
+				prices[key] = p.USD
+				refreshed = append(refreshed, key)
+			}
+		}
+	}
+
+	for chainID, addresses := range contracts {
+		live, err := j.priceClient.GetTokenPricesByContract(ctx, chainID, addresses)
+		if err != nil {
+			logger.Warn("Failed to refresh stale token prices, using stored prices", "chainId", chainID, "tokens", len(addresses), "error", err)
+			continue
+		}
+		for _, address := range addresses {
+			p, ok := live[address]
+			if !ok {
+				continue
+			}
+			key := tokenKey{Address: address, ChainID: chainID}
+			prices[key] = p.USD
+			refreshed = append(refreshed, key)
+		}
+	}
+	return refreshed
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAlertPriceClient serves prices by CoinGecko ID and by chain and address
+type fakeAlertPriceClient struct {
+	byID       external.PriceResponse
+	byContract map[int]external.PriceResponse
+	failChain  int
+	calls      []string
+}
+
+func (f *fakeAlertPriceClient) GetTokenPrices(ctx context.Context, tokenIDs []string) (external.PriceResponse, error) {
+	f.calls = append(f.calls, "ids")
+	prices := external.PriceResponse{}
+	for _, id := range tokenIDs {
+		if p, ok := f.byID[id]; ok {
+			prices[id] = p
+		}
+	}
+	return prices, nil
+}
+
+func (f *fakeAlertPriceClient) GetTokenPricesByContract(ctx context.Context, chainID int, addresses []string) (external.PriceResponse, error) {
+	f.calls = append(f.calls, "contracts")
+	if chainID == f.failChain {
+		return nil, errors.New("CoinGecko API error: 500")
+	}
+	prices := external.PriceResponse{}
+	for _, address := range addresses {
+		if p, ok := f.byContract[chainID][address]; ok {
+			prices[address] = p
+		}
+	}
+	return prices, nil
+}
+
+func TestRefreshStalePrices(t *testing.T) {
+	usdc := newTokenKey("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", 1)
+	eth := newTokenKey("0x0000000000000000000000000000000000000000", 1)
+	arbETH := newTokenKey("0x0000000000000000000000000000000000000000", 42161)
+	polygonUSDC := newTokenKey("0x3c499c542cef5e3811e1192ce70d8cc03d5c3359", 137)
+	client := &fakeAlertPriceClient{
+		byID: external.PriceResponse{"ethereum": {USD: 3400}},
+		byContract: map[int]external.PriceResponse{
+			1: {usdc.Address: {USD: 0.9998}},
+		},
+		failChain: 137,
+	}
+	job := &AlertEvaluatorJob{priceClient: client}
+
+	// Stored prices are replaced; one failing chain keeps its stored price
+	prices := map[tokenKey]float64{usdc: 0.97, polygonUSDC: 1.01}
+	refreshed := job.refreshStalePrices(context.Background(), []tokenKey{usdc, eth, arbETH, polygonUSDC}, prices)
+
+	assert.ElementsMatch(t, []tokenKey{usdc, eth, arbETH}, refreshed)
+	assert.Equal(t, map[tokenKey]float64{usdc: 0.9998, eth: 3400, arbETH: 3400, polygonUSDC: 1.01}, prices)
+}
+
+func TestRefreshStalePrices_NoSymbolFallback(t *testing.T) {
+	// A token calling itself USDC at another address isn't listed by CoinGecko and
+	// mustn't borrow USDC's price
+	fake := newTokenKey("0x1111111111111111111111111111111111111111", 1)
+	client := &fakeAlertPriceClient{
+		byID: external.PriceResponse{"usd-coin": {USD: 1}},
+		byContract: map[int]external.PriceResponse{
+			1: {"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48": {USD: 1}},
+		},
+	}
+	job := &AlertEvaluatorJob{priceClient: client}
+
+	prices := map[tokenKey]float64{}
+	refreshed := job.refreshStalePrices(context.Background(), []tokenKey{fake}, prices)
+
+	assert.Empty(t, refreshed)
+	assert.Empty(t, prices)
+	assert.Equal(t, []string{"contracts"}, client.calls, "only the contract lookup is asked")
+}
@@ -0,0 +1,162 @@
+package jobs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// alertEvaluatorWorkers bounds how many shards are evaluated concurrently
+	alertEvaluatorWorkers = 8
+
+	// alertShardsPerType is the number of shards each alert type is split into
+	alertShardsPerType = 16
+)
+
+// alertShard is a unit of parallel evaluation: alerts of one type whose
+// lookup keys hash to the same shard
+type alertShard struct {
+	alertType string
+	index     int
+	alerts    []models.Alert
+}
+
+// shardAlerts splits each type's alerts into shards by lookup key so every
+// token, address or pool is fetched by exactly one worker
+func (j *AlertEvaluatorJob) shardAlerts(alertsByType map[string][]models.Alert) []alertShard {
+	var shards []alertShard
+
+	for alertType, alerts := range alertsByType {
+		buckets := make([][]models.Alert, alertShardsPerType)
+		for _, alert := range alerts {
+			i := shardIndex(alertShardKey(alert), alertShardsPerType)
+			buckets[i] = append(buckets[i], alert)
+		}
+
+		for i, bucket := range buckets {
+			if len(bucket) > 0 {
+				shards = append(shards, alertShard{alertType: alertType, index: i, alerts: bucket})
+			}
+		}
+	}
+
+	// Largest shards first so stragglers don't start last
+	sort.Slice(shards, func(a, b int) bool {
+		return len(shards[a].alerts) > len(shards[b].alerts)
+	})
+
+	return shards
+}
+
+// alertShardKey is the lookup key alerts are grouped on
+func alertShardKey(alert models.Alert) string {
+	key := strings.ToLower(alert.Target.Identifier)
+	switch alert.Target.Type {
+	case "token":
+		key = fmt.Sprintf("%s-%d", key, alert.Target.ChainID)
+	case models.AlertTargetTypePortfolio:
+		key = alert.UserID.String()
+	}
+	return key
+}
+
+func shardIndex(key string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// typeMetrics aggregates evaluation cost for one alert type within a run
+type typeMetrics struct {
+	alerts    int
+	triggered int
+	shards    int
+	errors    int
+	total     time.Duration
+	slowest   time.Duration
+}
+
+// evaluationMetrics collects per-type timings across concurrently evaluated shards
+type evaluationMetrics struct {
+	mu     sync.Mutex
+	byType map[string]*typeMetrics
+}
+
+func newEvaluationMetrics() *evaluationMetrics {
+	return &evaluationMetrics{byType: make(map[string]*typeMetrics)}
+}
+
+func (m *evaluationMetrics) record(alertType string, alerts, triggered int, elapsed time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tm, ok := m.byType[alertType]
+	if !ok {
+		tm = &typeMetrics{}
+		m.byType[alertType] = tm
+	}
+
+	tm.alerts += alerts
+	tm.triggered += triggered
+	tm.shards++
+	tm.total += elapsed
+	if elapsed > tm.slowest {
+		tm.slowest = elapsed
+	}
+	if err != nil {
+		tm.errors++
+	}
+}
+
+// typeMetricsSnapshot is one alert type's line in the published metrics
+type typeMetricsSnapshot struct {
+	Alerts         int   `json:"alerts"`
+	Triggered      int   `json:"triggered"`
+	Shards         int   `json:"shards"`
+	Errors         int   `json:"errors"`
+	TotalMs        int64 `json:"total_ms"`
+	SlowestShardMs int64 `json:"slowest_shard_ms"`
+}
+
+// snapshot returns the metrics of each alert type
+func (m *evaluationMetrics) snapshot() map[string]typeMetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]typeMetricsSnapshot, len(m.byType))
+	for alertType, tm := range m.byType {
+		out[alertType] = typeMetricsSnapshot{
+			Alerts:         tm.alerts,
+			Triggered:      tm.triggered,
+			Shards:         tm.shards,
+			Errors:         tm.errors,
+			TotalMs:        tm.total.Milliseconds(),
+			SlowestShardMs: tm.slowest.Milliseconds(),
+		}
+	}
+	return out
+}
+
+// log emits one line per alert type with its evaluation time
+func (m *evaluationMetrics) log() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for alertType, tm := range m.byType {
+		logger.Info("Alert type evaluated",
+			"type", alertType,
+			"alerts", tm.alerts,
+			"triggered", tm.triggered,
+			"shards", tm.shards,
+			"errors", tm.errors,
+			"totalMs", tm.total.Milliseconds(),
+			"slowestShardMs", tm.slowest.Milliseconds())
+	}
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardIndex(t *testing.T) {
+	// FNV-1a doesn't change between processes or releases, so neither do shards
+	assert.Equal(t, 0, shardIndex("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48-1", alertShardsPerType))
+	assert.Equal(t, 1, shardIndex("pool-1", alertShardsPerType))
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("0x%040x-1", i)
+		index := shardIndex(key, alertShardsPerType)
+		assert.Equal(t, index, shardIndex(key, alertShardsPerType))
+		assert.GreaterOrEqual(t, index, 0)
+		assert.Less(t, index, alertShardsPerType)
+	}
+}
+
+func TestAlertShardKey(t *testing.T) {
+	token := func(identifier string, chainID int) models.Alert {
+		return models.Alert{Target: models.AlertTarget{Type: "token", Identifier: identifier, ChainID: chainID}}
+	}
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	// Tokens are keyed on address and chain, whatever the address's case
+	assert.Equal(t, alertShardKey(token(usdc, 1)), alertShardKey(token(usdc[:2]+"a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", 1)))
+	assert.NotEqual(t, alertShardKey(token(usdc, 1)), alertShardKey(token(usdc, 137)))
+
+	// Pools and addresses on their identifier, portfolios on their owner
+	pool := models.Alert{Target: models.AlertTarget{Type: "pool", Identifier: "Pool-1"}}
+	assert.Equal(t, "pool-1", alertShardKey(pool))
+	userID := uuid.New()
+	portfolio := models.Alert{UserID: userID, Target: models.AlertTarget{Type: models.AlertTargetTypePortfolio}}
+	assert.Equal(t, userID.String(), alertShardKey(portfolio))
+}
+
+func TestShardAlerts(t *testing.T) {
+	alert := func(alertType, targetType, identifier string, chainID int) models.Alert {
+		return models.Alert{
+			ID:     uuid.New(),
+			UserID: uuid.New(),
+			Type:   alertType,
+			Target: models.AlertTarget{Type: targetType, Identifier: identifier, ChainID: chainID},
+		}
+	}
+	byType := map[string][]models.Alert{
+		models.AlertTypePriceAbove: {
+			alert(models.AlertTypePriceAbove, "token", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", 1),
+			alert(models.AlertTypePriceAbove, "token", "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", 1),
+		},
+		models.AlertTypeAPRChange: {
+			alert(models.AlertTypeAPRChange, "pool", "pool-1", 0),
+			alert(models.AlertTypeAPRChange, "pool", "pool-1", 0),
+		},
+	}
+	for i := 0; i < 40; i++ {
+		byType[models.AlertTypePriceAbove] = append(byType[models.AlertTypePriceAbove],
+			alert(models.AlertTypePriceAbove, "token", fmt.Sprintf("0x%040x", i), 1))
+		byType[models.AlertTypeAPRChange] = append(byType[models.AlertTypeAPRChange],
+			alert(models.AlertTypeAPRChange, "pool", fmt.Sprintf("pool-%d", i+2), 0))
+	}
+
+	job := &AlertEvaluatorJob{}
+	type placement struct {
+		alertType string
+		index     int
+	}
+	place := func(shards []alertShard) map[uuid.UUID]placement {
+		placed := make(map[uuid.UUID]placement)
+		for _, shard := range shards {
+			for _, a := range shard.alerts {
+				_, dup := placed[a.ID]
+				require.False(t, dup, "alert %s is in two shards", a.ID)
+				assert.Equal(t, shard.alertType, a.Type)
+				placed[a.ID] = placement{shard.alertType, shard.index}
+			}
+		}
+		return placed
+	}
+
+	shards := job.shardAlerts(byType)
+	placed := place(shards)
+	assert.Len(t, placed, 84)
+
+	// Largest shards go first
+	for i := 1; i < len(shards); i++ {
+		assert.GreaterOrEqual(t, len(shards[i-1].alerts), len(shards[i].alerts))
+	}
+
+	// Alerts on the same token or pool share a shard
+	prices, pools := byType[models.AlertTypePriceAbove], byType[models.AlertTypeAPRChange]
+	assert.Equal(t, placed[prices[0].ID], placed[prices[1].ID])
+	assert.Equal(t, placed[pools[0].ID], placed[pools[1].ID])
+	for _, alerts := range byType {
+		for _, a := range alerts {
+			assert.Equal(t, shardIndex(alertShardKey(a), alertShardsPerType), placed[a.ID].index)
+		}
+	}
+
+	// Sharding the same alerts again places each one where it was
+	assert.Equal(t, placed, place(job.shardAlerts(byType)))
+}
+
+func TestEvaluationMetrics(t *testing.T) {
+	metrics := newEvaluationMetrics()
+
+	// Shards finish concurrently; each failed one counts once against its type
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%4 == 0 {
+				err = fmt.Errorf("shard %d failed", i)
+			}
+			metrics.record(models.AlertTypePriceAbove, 10, 1, time.Duration(i+1)*time.Millisecond, err)
+		}(i)
+	}
+	wg.Wait()
+	metrics.record(models.AlertTypeAPRChange, 3, 0, 5*time.Millisecond, nil)
+
+	assert.Equal(t, map[string]typeMetricsSnapshot{
+		models.AlertTypePriceAbove: {Alerts: 80, Triggered: 8, Shards: 8, Errors: 2, TotalMs: 36, SlowestShardMs: 8},
+		models.AlertTypeAPRChange:  {Alerts: 3, Shards: 1, TotalMs: 5, SlowestShardMs: 5},
+	}, metrics.snapshot())
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// alertTargetBatchSize caps the alerts expired per run; the rest wait for the next one
+const alertTargetBatchSize = 500
+
+// AlertTargetJob expires the alerts whose pool or token was deactivated or delisted,
+// which would otherwise be evaluated forever without ever firing, and tells each owner
+// once so they can re-point the alert to a successor
+type AlertTargetJob struct {
+	targetRepo repos.AlertTargetRepository
+	outbox     services.NotificationOutbox
+	goneAfter  time.Duration
+	clock      clock.Clock
+}
+
+func NewAlertTargetJob(targetRepo repos.AlertTargetRepository, outbox services.NotificationOutbox, goneAfter time.Duration) *AlertTargetJob {
+	return &AlertTargetJob{
+		targetRepo: targetRepo,
+		outbox:     outbox,
+		goneAfter:  goneAfter,
+		clock:      clock.System,
+	}
+}
+
+// Run expires the alerts whose target is gone
+func (j *AlertTargetJob) Run(ctx context.Context) error {
+	now := j.clock.Now()
+	gone, err := j.targetRepo.GetGone(ctx, now.Add(-j.goneAfter), alertTargetBatchSize)
+	if err != nil {
+		return err
+	}
+
+	expired := 0
+	for _, g := range gone {
+		outboxID, ok, err := j.targetRepo.Expire(ctx, g.AlertID, g.Reason, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		expired++
+		logger.Info("Expired alert on gone target", "alertId", g.AlertID, "reason", g.Reason)
+
+		// A failed send stays in the outbox for the outbox job to retry
+		if j.outbox != nil {
+			if err := j.outbox.Dispatch(ctx, outboxID); err != nil {
+				logger.Warn("Failed to dispatch alert expiry notification", "alertId", g.AlertID, "error", err)
+			}
+		}
+	}
+
+	if expired > 0 {
+		logger.Info("Expired alerts on gone targets", "expired", expired)
+	}
+	return nil
+}
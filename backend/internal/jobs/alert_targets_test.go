@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAlertTargetRepo struct {
+	repos.AlertTargetRepository
+	gone        []*models.GoneAlertTarget
+	staleBefore time.Time
+	// expired are the alerts expired so far, by reason
+	expired map[uuid.UUID]string
+}
+
+func (r *memoryAlertTargetRepo) GetGone(ctx context.Context, staleBefore time.Time, limit int) ([]*models.GoneAlertTarget, error) {
+	r.staleBefore = staleBefore
+	return r.gone, nil
+}
+
+func (r *memoryAlertTargetRepo) Expire(ctx context.Context, alertID uuid.UUID, reason string, at time.Time) (uuid.UUID, bool, error) {
+	if _, ok := r.expired[alertID]; ok {
+		return uuid.Nil, false, nil
+	}
+	r.expired[alertID] = reason
+	return alertID, true, nil
+}
+
+// recordingOutbox records the rows dispatched, failing every send when failing is set
+type recordingOutbox struct {
+	services.NotificationOutbox
+	dispatched []uuid.UUID
+	failing    bool
+}
+
+func (o *recordingOutbox) Dispatch(ctx context.Context, id uuid.UUID) error {
+	if o.failing {
+		return errors.New("smtp unavailable")
+	}
+	o.dispatched = append(o.dispatched, id)
+	return nil
+}
+
+func TestAlertTargetJobExpiresOnce(t *testing.T) {
+	pool := &models.GoneAlertTarget{AlertID: uuid.New(), Reason: models.AlertExpiredPoolDeactivated}
+	token := &models.GoneAlertTarget{AlertID: uuid.New(), Reason: models.AlertExpiredTokenDelisted}
+	repo := &memoryAlertTargetRepo{
+		gone:    []*models.GoneAlertTarget{pool, token},
+		expired: map[uuid.UUID]string{},
+	}
+	outbox := &recordingOutbox{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	job := NewAlertTargetJob(repo, outbox, 72*time.Hour)
+	job.clock = clock.NewFake(now)
+
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, now.Add(-72*time.Hour), repo.staleBefore)
+	assert.Equal(t, map[uuid.UUID]string{pool.AlertID: pool.Reason, token.AlertID: token.Reason}, repo.expired)
+	assert.Equal(t, []uuid.UUID{pool.AlertID, token.AlertID}, outbox.dispatched)
+
+	// Alerts expired by an earlier run aren't live any more, so their owners aren't told again
+	require.NoError(t, job.Run(context.Background()))
+	assert.Len(t, outbox.dispatched, 2)
+}
+
+func TestAlertTargetJobKeepsExpiringWhenSendFails(t *testing.T) {
+	repo := &memoryAlertTargetRepo{
+		gone: []*models.GoneAlertTarget{
+			{AlertID: uuid.New(), Reason: models.AlertExpiredPoolNotFound},
+			{AlertID: uuid.New(), Reason: models.AlertExpiredTokenNotFound},
+		},
+		expired: map[uuid.UUID]string{},
+	}
+	job := NewAlertTargetJob(repo, &recordingOutbox{failing: true}, time.Hour)
+
+	require.NoError(t, job.Run(context.Background()), "failed sends are left for the outbox job")
+	assert.Len(t, repo.expired, 2)
+}
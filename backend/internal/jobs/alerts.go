@@ -2,31 +2,108 @@ package jobs
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
-	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/internal/services"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/external"
 	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/sync/errgroup"
 )
 
 type AlertEvaluatorJob struct {
-	db           *pgxpool.Pool
-	alertService services.AlertService
-	alertRepo    repos.AlertRepository
+	db                *pgxpool.Pool
+	alertService      services.AlertService
+	alertRepo         repos.AlertRepository
+	priceClient       alertPriceClient
+	blockchainService *blockchain.BlockchainService
+	valuationRepo     repos.WalletValuationRepository
+	savedSearchRepo   repos.SavedSearchRepository
+	yieldPoolRepo     repos.YieldPoolRepository
+	rewardLockRepo    repos.RewardLockRepository
+	balanceRepo       repos.BalanceRepository
+	budgets           budgetProgressSource
+	coverageRepo      repos.AlertCoverageRepository
+	contracts         *services.ContractService
+	evaluators        *services.AlertEvaluatorRegistry
+	// maxDataAge is how old prices may be before alerts reading them are skipped
+	maxDataAge time.Duration
+	// analytics hears of triggered alerts; may be nil
+	analytics services.EventTracker
+	clock     clock.Clock
+	// lastMetrics holds the per-type timings of the latest full run
+	lastMetrics atomic.Pointer[evaluationMetrics]
 }
 
-func NewAlertEvaluatorJob(db *pgxpool.Pool, alertService services.AlertService, alertRepo repos.AlertRepository) *AlertEvaluatorJob {
-	return &AlertEvaluatorJob{
-		db:           db,
-		alertService: alertService,
-		alertRepo:    alertRepo,
+// NewAlertEvaluatorJob creates the evaluator. priceClient refreshes stale DB prices and
+// blockchainService supplies gas prices for composite rules; both may be nil. budgetService
+// works out spending against budgets for budget alerts. Alert types beyond the built-in
+// ones are evaluated by evaluators registered with services.RegisterAlertEvaluator.
+func NewAlertEvaluatorJob(db *pgxpool.Pool, alertService services.AlertService, alertRepo repos.AlertRepository, priceClient *external.CoinGeckoClient, blockchainService *blockchain.BlockchainService, budgetService *services.BudgetService) *AlertEvaluatorJob {
+	j := &AlertEvaluatorJob{
+		db:                db,
+		alertService:      alertService,
+		alertRepo:         alertRepo,
+		blockchainService: blockchainService,
+		valuationRepo:     repos.NewWalletValuationRepository(db),
+		savedSearchRepo:   repos.NewSavedSearchRepository(db),
+		yieldPoolRepo:     repos.NewYieldPoolRepository(db),
+		rewardLockRepo:    repos.NewRewardLockRepository(db),
+		balanceRepo:       repos.NewBalanceRepository(db),
+		budgets:           budgetService,
+		coverageRepo:      repos.NewAlertCoverageRepository(db),
+		contracts:         services.NewContractService(repos.NewContractRepository(db)),
+		maxDataAge:        alertDataMaxAge,
+		clock:             clock.System,
+	}
+	if priceClient != nil {
+		j.priceClient = priceClient
+	}
+	j.evaluators = j.builtinEvaluators()
+	return j
+}
+
+// SetAnalytics reports triggered alerts as alert_triggered usage events
+func (j *AlertEvaluatorJob) SetAnalytics(analytics services.EventTracker) {
+	j.analytics = analytics
+}
+
+// SetMaxDataAge sets how old prices may be before alerts reading them are skipped
+func (j *AlertEvaluatorJob) SetMaxDataAge(maxAge time.Duration) {
+	if maxAge > 0 {
+		j.maxDataAge = maxAge
 	}
 }
 
+// PublishMetrics exposes the per-type timings of the latest full run as the expvar
+// variable name
+func (j *AlertEvaluatorJob) PublishMetrics(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		if metrics := j.lastMetrics.Load(); metrics != nil {
+			return metrics.snapshot()
+		}
+		return map[string]typeMetricsSnapshot{}
+	}))
+}
+
+// coolingDown reports whether the alert triggered within the last hour, resting until
+// it's evaluated again
+func (j *AlertEvaluatorJob) coolingDown(alert *models.Alert) bool {
+	return alert.LastTriggeredAt != nil && j.clock.Now().Sub(*alert.LastTriggeredAt) < time.Hour
+}
+
 // Use alert types from models
 const (
 	AlertTypePriceAbove      = models.AlertTypePriceAbove
@@ -35,6 +112,15 @@ const (
 	AlertTypeApproval        = models.AlertTypeApproval
 	AlertTypeLiquidityChange = models.AlertTypeLiquidityChange
 	AlertTypeAPRChange       = models.AlertTypeAPRChange
+	AlertTypeComposite       = models.AlertTypeComposite
+	AlertTypeHealthFactor    = models.AlertTypeHealthFactor
+	AlertTypePortfolioValue  = models.AlertTypePortfolioValue
+	AlertTypePositionAPYDrop = models.AlertTypePositionAPYDrop
+	AlertTypeILThreshold     = models.AlertTypeILThreshold
+	AlertTypeNewMatch        = models.AlertTypeNewMatch
+	AlertTypeUnlockReminder  = models.AlertTypeUnlockReminder
+	AlertTypeBalanceChange   = models.AlertTypeBalanceChange
+	AlertTypeBudgetExceeded  = models.AlertTypeBudgetExceeded
 )
 
 // Run executes the alert evaluation job
@@ -54,29 +140,125 @@ func (j *AlertEvaluatorJob) Run(ctx context.Context) error {
 
 	logger.Info("Evaluating alerts", "count", len(alerts))
 
-	// Group alerts by type for batch processing
-	alertsByType := j.groupAlertsByType(alerts)
+	// Group alerts by type, then shard each type by lookup key so alerts that
+	// share a token, address or pool are evaluated together
+	shards := j.shardAlerts(j.groupAlertsByType(alerts))
+	metrics := newEvaluationMetrics()
+	coverage := newCoverageRun(j.clock.Now())
+	ctx = withCoverageRun(ctx, coverage)
+
+	var triggered atomic.Int64
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(alertEvaluatorWorkers)
+
+	for _, shard := range shards {
+		shard := shard
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
 
-	// Evaluate each type of alert
-	triggered := 0
-	for alertType, typeAlerts := range alertsByType {
-		count, err := j.evaluateAlertType(ctx, alertType, typeAlerts)
-		if err != nil {
-			logger.Error("Failed to evaluate alert type",
-				"type", alertType,
-				"error", err)
-			continue
-		}
-		triggered += count
+			start := j.clock.Now()
+			count, err := j.evaluateAlertType(gctx, shard.alertType, shard.alerts)
+			metrics.record(shard.alertType, len(shard.alerts), count, j.clock.Now().Sub(start), err)
+			coverage.checked(shard.alerts, err != nil)
+			if err != nil {
+				logger.Error("Failed to evaluate alert shard",
+					"type", shard.alertType,
+					"shard", shard.index,
+					"error", err)
+				return nil
+			}
+			triggered.Add(int64(count))
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
 	}
 
+	metrics.log()
+	j.lastMetrics.Store(metrics)
+	j.recordCoverage(ctx, coverage)
+	if skipped := coverage.skipped(); skipped > 0 {
+		logger.Warn("Skipped alerts on stale data",
+			"skipped", skipped,
+			"maxDataAge", j.maxDataAge)
+		MarkDegraded(ctx, fmt.Sprintf("skipped %d alerts on data older than %s", skipped, j.maxDataAge))
+	}
 	logger.Info("Alert evaluation completed",
 		"total", len(alerts),
-		"triggered", triggered)
+		"shards", len(shards),
+		"triggered", triggered.Load())
 
 	return nil
 }
 
+// EvaluateAlert evaluates one alert outside the schedule, reporting whether it triggered.
+// Alerts still cooling down from their last trigger are skipped as in a scheduled run.
+func (j *AlertEvaluatorJob) EvaluateAlert(ctx context.Context, alertID uuid.UUID) (bool, error) {
+	alert, err := j.alertRepo.GetByID(ctx, alertID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get alert: %w", err)
+	}
+	if alert.Status != models.AlertStatusActive {
+		return false, fmt.Errorf("alert %s is %s", alertID, alert.Status)
+	}
+	if j.coolingDown(alert) {
+		return false, nil
+	}
+
+	coverage := newCoverageRun(j.clock.Now())
+	count, err := j.evaluateAlertType(withCoverageRun(ctx, coverage), alert.Type, []models.Alert{*alert})
+	coverage.checked([]models.Alert{*alert}, err != nil)
+	j.recordCoverage(ctx, coverage)
+	return count > 0, err
+}
+
+// EvaluateAddresses evaluates the active alerts targeting any of the addresses outside
+// the schedule, such as when a webhook reports activity on them, returning how many
+// triggered. Alerts still cooling down from their last trigger are skipped.
+func (j *AlertEvaluatorJob) EvaluateAddresses(ctx context.Context, addresses []string) (int, error) {
+	targeted := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		targeted[addr.Normalize(address)] = true
+	}
+
+	alerts, err := j.getActiveAlerts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get active alerts: %w", err)
+	}
+	var matched []models.Alert
+	for _, alert := range alerts {
+		if alert.Target.Type != "address" || !targeted[addr.Normalize(alert.Target.Identifier)] {
+			continue
+		}
+		if j.coolingDown(&alert) {
+			continue
+		}
+		matched = append(matched, alert)
+	}
+	if len(matched) == 0 {
+		return 0, nil
+	}
+
+	coverage := newCoverageRun(j.clock.Now())
+	ctx = withCoverageRun(ctx, coverage)
+	triggered := 0
+	for alertType, typed := range j.groupAlertsByType(matched) {
+		count, err := j.evaluateAlertType(ctx, alertType, typed)
+		coverage.checked(typed, err != nil)
+		if err != nil {
+			logger.Error("Failed to evaluate address alerts", "type", alertType, "error", err)
+			continue
+		}
+		triggered += count
+	}
+	j.recordCoverage(ctx, coverage)
+	return triggered, nil
+}
+
 // getActiveAlerts retrieves all active alerts from the database
 func (j *AlertEvaluatorJob) getActiveAlerts(ctx context.Context) ([]models.Alert, error) {
 	return j.alertRepo.GetActiveAlerts(ctx)
@@ -91,46 +273,74 @@ func (j *AlertEvaluatorJob) groupAlertsByType(alerts []models.Alert) map[string]
 	return grouped
 }
 
-// evaluateAlertType evaluates all alerts of a specific type
+// evaluateAlertType evaluates all alerts of a specific type with its registered evaluator.
+// Evaluators that can't batch are run alert by alert and their triggers fired here.
 func (j *AlertEvaluatorJob) evaluateAlertType(ctx context.Context, alertType string, alerts []models.Alert) (int, error) {
-	switch alertType {
-	case AlertTypePriceAbove, AlertTypePriceBelow:
-		return j.evaluatePriceAlerts(ctx, alerts)
-	case AlertTypeLargeTransfer:
-		return j.evaluateTransferAlerts(ctx, alerts)
-	case AlertTypeApproval:
-		return j.evaluateApprovalAlerts(ctx, alerts)
-	case AlertTypeLiquidityChange:
-		return j.evaluateLiquidityAlerts(ctx, alerts)
-	case AlertTypeAPRChange:
-		return j.evaluateAPRAlerts(ctx, alerts)
-	default:
+	evaluator, ok := j.evaluators.Get(alertType)
+	if !ok {
+		evaluator, ok = services.LookupAlertEvaluator(alertType)
+	}
+	if !ok {
 		logger.Warn("Unknown alert type", "type", alertType)
 		return 0, nil
 	}
+
+	if batch, ok := evaluator.(services.AlertBatchEvaluator); ok {
+		return batch.EvaluateBatch(ctx, alerts, j.trigger)
+	}
+
+	triggered := 0
+	for i := range alerts {
+		alert := &alerts[i]
+		fired, payload, err := evaluator.Evaluate(ctx, alert)
+		if err != nil {
+			logger.Error("Failed to evaluate alert",
+				"alertId", alert.ID,
+				"type", alertType,
+				"error", err)
+			coverageRunFrom(ctx).failed(alert.ID)
+			continue
+		}
+		if !fired {
+			continue
+		}
+		if err := j.trigger(ctx, alert, payload); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+	return triggered, nil
 }
 
 // evaluatePriceAlerts checks price-based alerts
-func (j *AlertEvaluatorJob) evaluatePriceAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+func (j *AlertEvaluatorJob) evaluatePriceAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
 	// Get unique tokens to check
-	tokenMap := make(map[string][]models.Alert)
+	tokenMap := make(map[tokenKey][]models.Alert)
 	for _, alert := range alerts {
 		if alert.Target.Type == "token" {
-			key := fmt.Sprintf("%s-%d", alert.Target.Identifier, alert.Target.ChainID)
+			key := newTokenKey(alert.Target.Identifier, alert.Target.ChainID)
 			tokenMap[key] = append(tokenMap[key], alert)
 		}
 	}
 
 	// Fetch current prices
-	prices, err := j.getTokenPrices(ctx, tokenMap)
+	prices, stale, err := j.getTokenPrices(ctx, tokenMap)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get token prices: %w", err)
 	}
 
 	// Evaluate each alert
+	coverage := coverageRunFrom(ctx)
 	triggered := 0
-	for tokenKey, tokenAlerts := range tokenMap {
-		price, exists := prices[tokenKey]
+	for key, tokenAlerts := range tokenMap {
+		if stale[key] {
+			coverage.skippedStale(tokenAlerts)
+			continue
+		}
+		price, exists := prices[key]
 		if !exists {
 			continue
 		}
@@ -139,10 +349,10 @@ func (j *AlertEvaluatorJob) evaluatePriceAlerts(ctx context.Context, alerts []mo
 			if j.evaluatePriceCondition(&alert, price) {
 				triggeredValue := map[string]interface{}{
 					"currentPrice": price,
-					"tokenKey":     tokenKey,
+					"tokenKey":     fmt.Sprintf("%s-%d", alert.Target.Identifier, alert.Target.ChainID),
 				}
 				
-				if err := j.alertService.TriggerAlert(ctx, alert.ID, triggeredValue); err != nil {
+				if err := trigger(ctx, &alert, triggeredValue); err != nil {
 					logger.Error("Failed to trigger alert",
 						"alertId", alert.ID,
 						"error", err)
@@ -175,8 +385,10 @@ func (j *AlertEvaluatorJob) evaluatePriceCondition(alert *models.Alert, currentP
 	}
 }
 
-// evaluateTransferAlerts checks for large transfers
-func (j *AlertEvaluatorJob) evaluateTransferAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+// evaluateTransferAlerts checks for large transfers. Thresholds in USD are compared
+// with each transfer valued at its chain's native token price; alerts whose transfers
+// could only be valued at a price too old to trust are skipped.
+func (j *AlertEvaluatorJob) evaluateTransferAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
 	// Group by address
 	addressMap := make(map[string][]models.Alert)
 	for _, alert := range alerts {
@@ -185,9 +397,10 @@ func (j *AlertEvaluatorJob) evaluateTransferAlerts(ctx context.Context, alerts [
 		}
 	}
 
-	triggered := 0
+	// Get recent large transfers, and the native tokens to price them by
+	transferMap := make(map[string][]Transfer, len(addressMap))
+	nativeMap := make(map[tokenKey][]models.Alert)
 	for address, addrAlerts := range addressMap {
-		// Get recent large transfers
 		transfers, err := j.getLargeTransfers(ctx, address)
 		if err != nil {
 			logger.Error("Failed to get transfers",
@@ -195,40 +408,59 @@ func (j *AlertEvaluatorJob) evaluateTransferAlerts(ctx context.Context, alerts [
 				"error", err)
 			continue
 		}
+		transferMap[address] = transfers
 
-		for _, alert := range addrAlerts {
-			if alert.Conditions.Threshold == nil {
-				continue
+		for _, transfer := range transfers {
+			key := newTokenKey(blockchain.NativeTokenAddress, transfer.ChainID)
+			if _, ok := nativeMap[key]; !ok {
+				nativeMap[key] = []models.Alert{}
 			}
-
-			threshold, ok := new(big.Int).SetString(*alert.Conditions.Threshold, 10)
-			if !ok {
-				continue
+			for _, alert := range addrAlerts {
+				if alert.Conditions.ThresholdUSD != nil {
+					nativeMap[key] = append(nativeMap[key], alert)
+				}
 			}
+		}
+	}
 
-			// Check if any transfer exceeds threshold
+	prices, stale, err := j.getTokenPrices(ctx, nativeMap)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get native token prices: %w", err)
+	}
+
+	coverage := coverageRunFrom(ctx)
+	triggered := 0
+	for address, transfers := range transferMap {
+		for _, alert := range addressMap[address] {
+			fired, sawStale := false, false
 			for _, transfer := range transfers {
-				amount, ok := new(big.Int).SetString(transfer.Amount, 10)
-				if !ok {
+				key := newTokenKey(blockchain.NativeTokenAddress, transfer.ChainID)
+				var price *float64
+				if p, ok := prices[key]; ok {
+					price = &p
+				}
+
+				triggeredValue, exceeds, err := transferExceeds(&alert, transfer, price)
+				if errors.Is(err, errNoPrice) && stale[key] {
+					sawStale = true
+				}
+				if err != nil || !exceeds {
 					continue
 				}
 
-				if amount.Cmp(threshold) > 0 {
-					triggeredValue := map[string]interface{}{
-						"transferAmount": transfer.Amount,
-						"threshold":      *alert.Conditions.Threshold,
-						"address":        address,
-					}
-					
-					if err := j.alertService.TriggerAlert(ctx, alert.ID, triggeredValue); err != nil {
-						logger.Error("Failed to trigger alert",
-							"alertId", alert.ID,
-							"error", err)
-					} else {
-						triggered++
-					}
-					break // Only trigger once per alert
+				fired = true
+				triggeredValue["address"] = address
+				if err := trigger(ctx, &alert, triggeredValue); err != nil {
+					logger.Error("Failed to trigger alert",
+						"alertId", alert.ID,
+						"error", err)
+				} else {
+					triggered++
 				}
+				break // Only trigger once per alert
+			}
+			if !fired && sawStale {
+				coverage.skippedStale([]models.Alert{alert})
 			}
 		}
 	}
@@ -236,8 +468,55 @@ func (j *AlertEvaluatorJob) evaluateTransferAlerts(ctx context.Context, alerts [
 	return triggered, nil
 }
 
+// errNoPrice is returned for a transfer a USD threshold can't be compared with
+var errNoPrice = errors.New("native token has no price")
+
+// transferExceeds reports whether a transfer is above an alert's threshold, with the
+// trigger payload recording the transfer in wei and, when priced, in USD. Transfers
+// without a price can't be compared with a USD threshold.
+func transferExceeds(alert *models.Alert, transfer Transfer, priceUSD *float64) (map[string]interface{}, bool, error) {
+	raw, err := amounts.ParseBaseUnits(transfer.Amount)
+	if err != nil {
+		return nil, false, err
+	}
+	decimals := amounts.NativeDecimals(models.EVMChain(transfer.ChainID))
+
+	triggeredValue := map[string]interface{}{
+		"transferAmount": transfer.Amount,
+		"chainId":        transfer.ChainID,
+	}
+	var valueUSD *decimal.Decimal
+	if priceUSD != nil {
+		value, err := amounts.USDValue(raw, decimals, *priceUSD)
+		if err == nil {
+			valueUSD = &value
+			triggeredValue["transferAmountUsd"] = value.Round(2).Float64()
+			triggeredValue["priceUsd"] = *priceUSD
+		}
+	}
+
+	conditions := alert.Conditions
+	switch {
+	case conditions.ThresholdUSD != nil:
+		if valueUSD == nil {
+			return nil, false, errNoPrice
+		}
+		triggeredValue["thresholdUsd"] = *conditions.ThresholdUSD
+		return triggeredValue, valueUSD.GreaterThan(decimal.NewFromFloat(*conditions.ThresholdUSD)), nil
+	case conditions.Threshold != nil:
+		threshold, err := amounts.ParseBaseUnits(*conditions.Threshold)
+		if err != nil {
+			return nil, false, err
+		}
+		triggeredValue["threshold"] = *conditions.Threshold
+		return triggeredValue, raw.Cmp(threshold) > 0, nil
+	default:
+		return nil, false, nil
+	}
+}
+
 // evaluateApprovalAlerts checks for new token approvals
-func (j *AlertEvaluatorJob) evaluateApprovalAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+func (j *AlertEvaluatorJob) evaluateApprovalAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
 	triggered := 0
 	
 	for _, alert := range alerts {
@@ -254,13 +533,16 @@ func (j *AlertEvaluatorJob) evaluateApprovalAlerts(ctx context.Context, alerts [
 			continue
 		}
 
-		if newApprovals > 0 {
+		if len(newApprovals) > 0 {
 			triggeredValue := map[string]interface{}{
-				"newApprovals": newApprovals,
+				"newApprovals": len(newApprovals),
 				"address":      alert.Target.Identifier,
 			}
+			if risky := j.riskySpenders(ctx, newApprovals); len(risky) > 0 {
+				triggeredValue["riskySpenders"] = risky
+			}
 			
-			if err := j.alertService.TriggerAlert(ctx, alert.ID, triggeredValue); err != nil {
+			if err := trigger(ctx, &alert, triggeredValue); err != nil {
 				logger.Error("Failed to trigger alert",
 					"alertId", alert.ID,
 					"error", err)
@@ -273,12 +555,13 @@ func (j *AlertEvaluatorJob) evaluateApprovalAlerts(ctx context.Context, alerts [
 	return triggered, nil
 }
 
-// evaluateLiquidityAlerts checks for liquidity changes in pools
-func (j *AlertEvaluatorJob) evaluateLiquidityAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+// evaluateLiquidityAlerts checks for liquidity changes in pools and protocols
+func (j *AlertEvaluatorJob) evaluateLiquidityAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
 	triggered := 0
+	tvlChanges := make(map[string]float64) // target -> change, so each pool or protocol is queried once
 	
 	for _, alert := range alerts {
-		if alert.Target.Type != "pool" {
+		if alert.Target.Type != "pool" && alert.Target.Type != "protocol" {
 			continue
 		}
 
@@ -288,23 +571,38 @@ func (j *AlertEvaluatorJob) evaluateLiquidityAlerts(ctx context.Context, alerts
 
 		changeThreshold := *alert.Conditions.ChangePercent
 
-		// Get pool TVL change
-		tvlChange, err := j.getPoolTVLChange(ctx, alert.Target.Identifier)
-		if err != nil {
-			logger.Error("Failed to get TVL change",
-				"pool", alert.Target.Identifier,
-				"error", err)
-			continue
+		// Get pool or protocol TVL change
+		cacheKey := alert.Target.Type + ":" + alert.Target.Identifier
+		tvlChange, cached := tvlChanges[cacheKey]
+		if !cached {
+			var err error
+			if alert.Target.Type == "protocol" {
+				tvlChange, err = j.getProtocolTVLChange(ctx, alert.Target.Identifier)
+			} else {
+				tvlChange, err = j.getPoolTVLChange(ctx, alert.Target.Identifier)
+			}
+			if err != nil {
+				logger.Error("Failed to get TVL change",
+					"targetType", alert.Target.Type,
+					"target", alert.Target.Identifier,
+					"error", err)
+				continue
+			}
+			tvlChanges[cacheKey] = tvlChange
 		}
 
 		if tvlChange > changeThreshold || tvlChange < -changeThreshold {
 			triggeredValue := map[string]interface{}{
 				"tvlChangePercent": tvlChange,
 				"threshold":        changeThreshold,
-				"poolId":           alert.Target.Identifier,
+			}
+			if alert.Target.Type == "protocol" {
+				triggeredValue["protocolSlug"] = alert.Target.Identifier
+			} else {
+				triggeredValue["poolId"] = alert.Target.Identifier
 			}
 			
-			if err := j.alertService.TriggerAlert(ctx, alert.ID, triggeredValue); err != nil {
+			if err := trigger(ctx, &alert, triggeredValue); err != nil {
 				logger.Error("Failed to trigger alert",
 					"alertId", alert.ID,
 					"error", err)
@@ -318,20 +616,29 @@ func (j *AlertEvaluatorJob) evaluateLiquidityAlerts(ctx context.Context, alerts
 }
 
 // evaluateAPRAlerts checks for APR changes in yield pools
-func (j *AlertEvaluatorJob) evaluateAPRAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+func (j *AlertEvaluatorJob) evaluateAPRAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
 	triggered := 0
+
+	// Fetch every pool's APR for this shard in one query
+	var poolIDs []string
+	for _, alert := range alerts {
+		if alert.Target.Type == "pool" {
+			poolIDs = append(poolIDs, alert.Target.Identifier)
+		}
+	}
+	aprs, err := j.getPoolAPRs(ctx, poolIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pool APRs: %w", err)
+	}
 	
 	for _, alert := range alerts {
 		if alert.Target.Type != "pool" {
 			continue
 		}
 
-		// Get current pool APR
-		currentAPR, err := j.getPoolAPR(ctx, alert.Target.Identifier)
-		if err != nil {
-			logger.Error("Failed to get pool APR",
-				"pool", alert.Target.Identifier,
-				"error", err)
+		currentAPR, exists := aprs[alert.Target.Identifier]
+		if !exists {
+			logger.Warn("Pool APR not found", "pool", alert.Target.Identifier)
 			continue
 		}
 
@@ -356,7 +663,7 @@ func (j *AlertEvaluatorJob) evaluateAPRAlerts(ctx context.Context, alerts []mode
 				"poolId":     alert.Target.Identifier,
 			}
 			
-			if err := j.alertService.TriggerAlert(ctx, alert.ID, triggeredValue); err != nil {
+			if err := trigger(ctx, &alert, triggeredValue); err != nil {
 				logger.Error("Failed to trigger alert",
 					"alertId", alert.ID,
 					"error", err)
@@ -371,43 +678,20 @@ func (j *AlertEvaluatorJob) evaluateAPRAlerts(ctx context.Context, alerts []mode
 
 // Helper methods to fetch data
 
-func (j *AlertEvaluatorJob) getTokenPrices(ctx context.Context, tokenMap map[string][]models.Alert) (map[string]float64, error) {
-	prices := make(map[string]float64)
-	
-	for tokenKey := range tokenMap {
-		// Parse token key (format: "address-chainId")
-		var address string
-		var chainID int
-		fmt.Sscanf(tokenKey, "%s-%d", &address, &chainID)
-
-		var price float64
-		err := j.db.QueryRow(ctx, `
-			SELECT price_usd 
-			FROM tokens 
-			WHERE address = $1 AND chain_id = $2`,
-			address, chainID).Scan(&price)
-		
-		if err == nil {
-			prices[tokenKey] = price
-		}
-	}
-
-	return prices, nil
-}
-
 type Transfer struct {
-	Amount string
+	Amount  string
+	ChainID int
 }
 
 func (j *AlertEvaluatorJob) getLargeTransfers(ctx context.Context, address string) ([]Transfer, error) {
 	rows, err := j.db.Query(ctx, `
-		SELECT value 
+		SELECT value, chain_id
 		FROM transactions 
 		WHERE (from_address = $1 OR to_address = $1)
 			AND timestamp > NOW() - INTERVAL '1 hour'
 			AND status = 'confirmed'
 		ORDER BY timestamp DESC`,
-		address)
+		addr.Normalize(address))
 	
 	if err != nil {
 		return nil, err
@@ -417,7 +701,7 @@ func (j *AlertEvaluatorJob) getLargeTransfers(ctx context.Context, address strin
 	var transfers []Transfer
 	for rows.Next() {
 		var t Transfer
-		if err := rows.Scan(&t.Amount); err == nil && t.Amount != "" {
+		if err := rows.Scan(&t.Amount, &t.ChainID); err == nil && t.Amount != "" {
 			transfers = append(transfers, t)
 		}
 	}
@@ -425,22 +709,73 @@ func (j *AlertEvaluatorJob) getLargeTransfers(ctx context.Context, address strin
 	return transfers, rows.Err()
 }
 
-func (j *AlertEvaluatorJob) getNewApprovals(ctx context.Context, address string, since *time.Time) (int, error) {
-	sinceTime := time.Now().Add(-1 * time.Hour)
+// getNewApprovals returns the spenders of the allowances an address granted since the
+// alert last triggered, one per allowance
+func (j *AlertEvaluatorJob) getNewApprovals(ctx context.Context, address string, since *time.Time) ([]models.ContractRef, error) {
+	sinceTime := j.clock.Now().Add(-1 * time.Hour)
 	if since != nil {
 		sinceTime = *since
 	}
 
-	var count int
-	err := j.db.QueryRow(ctx, `
-		SELECT COUNT(*) 
+	rows, err := j.db.Query(ctx, `
+		SELECT t.chain_id, ta.spender_address
 		FROM token_allowances ta
 		INNER JOIN wallets w ON w.id = ta.wallet_id
+		INNER JOIN tokens t ON t.id = ta.token_id
 		WHERE w.address = $1 
 			AND ta.created_at > $2`,
-		address, sinceTime).Scan(&count)
-	
-	return count, err
+		addr.Normalize(address), sinceTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spenders []models.ContractRef
+	for rows.Next() {
+		var spender models.ContractRef
+		if err := rows.Scan(&spender.ChainID, &spender.Address); err != nil {
+			return nil, err
+		}
+		spenders = append(spenders, spender)
+	}
+	return spenders, rows.Err()
+}
+
+// riskySpenders lists the spenders whose contracts score below services.RiskySecurityScore,
+// with their score and risk flags, in the order approved. Spenders not looked up yet
+// aren't listed.
+func (j *AlertEvaluatorJob) riskySpenders(ctx context.Context, spenders []models.ContractRef) []map[string]interface{} {
+	byChain := make(map[int][]string)
+	for _, spender := range spenders {
+		byChain[spender.ChainID] = append(byChain[spender.ChainID], spender.Address)
+	}
+	contracts := make(map[int]map[string]*models.ContractInfo, len(byChain))
+	for chainID, addresses := range byChain {
+		found, err := j.contracts.Lookup(ctx, chainID, addresses)
+		if err != nil {
+			logger.Warn("Failed to get spender contracts", "chainID", chainID, "error", err)
+			continue
+		}
+		contracts[chainID] = found
+	}
+
+	var risky []map[string]interface{}
+	listed := make(map[models.ContractRef]bool)
+	for _, spender := range spenders {
+		ref := models.ContractRef{ChainID: spender.ChainID, Address: addr.Normalize(spender.Address)}
+		info := contracts[ref.ChainID][ref.Address]
+		if info == nil || info.SecurityScore >= services.RiskySecurityScore || listed[ref] {
+			continue
+		}
+		listed[ref] = true
+		risky = append(risky, map[string]interface{}{
+			"address":       ref.Address,
+			"chainId":       ref.ChainID,
+			"securityScore": info.SecurityScore,
+			"riskFlags":     info.RiskFlags,
+		})
+	}
+	return risky
 }
 
 func (j *AlertEvaluatorJob) getPoolTVLChange(ctx context.Context, poolID string) (float64, error) {
@@ -463,13 +798,59 @@ func (j *AlertEvaluatorJob) getPoolTVLChange(ctx context.Context, poolID string)
 	return changePercent, nil
 }
 
-func (j *AlertEvaluatorJob) getPoolAPR(ctx context.Context, poolID string) (float64, error) {
-	var apr float64
+// getProtocolTVLChange returns the percent change between a protocol's latest TVL
+// snapshot and the latest one at least 24 hours older, or 0 without enough history
+func (j *AlertEvaluatorJob) getProtocolTVLChange(ctx context.Context, slug string) (float64, error) {
+	var currentTVL, previousTVL *float64
+
 	err := j.db.QueryRow(ctx, `
-		SELECT apy 
+		WITH protocol AS (
+			SELECT id FROM protocols WHERE slug = $1
+		)
+		SELECT
+			(SELECT h.tvl_usd::float8 FROM protocol_tvl_history h, protocol p
+			 WHERE h.protocol_id = p.id
+			 ORDER BY h.recorded_at DESC LIMIT 1),
+			(SELECT h.tvl_usd::float8 FROM protocol_tvl_history h, protocol p
+			 WHERE h.protocol_id = p.id AND h.recorded_at <= NOW() - INTERVAL '24 hours'
+			 ORDER BY h.recorded_at DESC LIMIT 1)`,
+		slug).Scan(&currentTVL, &previousTVL)
+	if err != nil {
+		return 0, err
+	}
+
+	if currentTVL == nil || previousTVL == nil || *previousTVL == 0 {
+		return 0, nil
+	}
+
+	return ((*currentTVL - *previousTVL) / *previousTVL) * 100, nil
+}
+
+// getPoolAPRs returns the current APY for each pool found, keyed by pool ID
+func (j *AlertEvaluatorJob) getPoolAPRs(ctx context.Context, poolIDs []string) (map[string]float64, error) {
+	aprs := make(map[string]float64, len(poolIDs))
+	if len(poolIDs) == 0 {
+		return aprs, nil
+	}
+
+	rows, err := j.db.Query(ctx, `
+		SELECT pool_id, apy 
 		FROM yield_pools 
-		WHERE pool_id = $1`,
-		poolID).Scan(&apr)
-	
-	return apr, err
-}
\ No newline at end of file
+		WHERE pool_id = ANY($1)`,
+		poolIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var poolID string
+		var apr float64
+		if err := rows.Scan(&poolID, &apr); err != nil {
+			return nil, err
+		}
+		aprs[poolID] = apr
+	}
+
+	return aprs, rows.Err()
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -21,8 +23,8 @@ type AlertEvaluatorTestSuite struct {
 
 func (s *AlertEvaluatorTestSuite) SetupTest() {
 	s.ctx = context.Background()
-	// Using nil db for unit tests - we'll test pure logic
-	s.job = NewAlertEvaluatorJob(nil)
+	// No dependencies for unit tests - we'll test pure logic
+	s.job = &AlertEvaluatorJob{}
 }
 
 // TestEvaluatePriceCondition tests price alert condition evaluation
@@ -73,15 +75,11 @@ func (s *AlertEvaluatorTestSuite) TestEvaluatePriceCondition() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			conditions := map[string]interface{}{
-				"price": tt.targetPrice,
-			}
-			conditionsJSON, _ := json.Marshal(conditions)
-
-			alert := &Alert{
-				ID:         uuid.New().String(),
+			targetPrice := tt.targetPrice
+			alert := &models.Alert{
+				ID:         uuid.New(),
 				Type:       tt.alertType,
-				Conditions: conditionsJSON,
+				Conditions: models.AlertConditions{Price: &targetPrice},
 			}
 
 			result := s.job.evaluatePriceCondition(alert, tt.currentPrice)
@@ -92,7 +90,7 @@ func (s *AlertEvaluatorTestSuite) TestEvaluatePriceCondition() {
 
 // TestGroupAlertsByType tests alert grouping functionality
 func (s *AlertEvaluatorTestSuite) TestGroupAlertsByType() {
-	alerts := []*Alert{
+	alerts := []models.Alert{
 		{Type: AlertTypePriceAbove},
 		{Type: AlertTypePriceBelow},
 		{Type: AlertTypePriceAbove},
@@ -114,12 +112,12 @@ func (s *AlertEvaluatorTestSuite) TestGroupAlertsByType() {
 func (s *AlertEvaluatorTestSuite) TestAlertTarget() {
 	testCases := []struct {
 		name     string
-		target   AlertTarget
+		target   models.AlertTarget
 		expected string
 	}{
 		{
 			name: "Token target",
-			target: AlertTarget{
+			target: models.AlertTarget{
 				Type:       "token",
 				Identifier: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
 				ChainID:    1,
@@ -128,7 +126,7 @@ func (s *AlertEvaluatorTestSuite) TestAlertTarget() {
 		},
 		{
 			name: "Address target",
-			target: AlertTarget{
+			target: models.AlertTarget{
 				Type:       "address",
 				Identifier: "0x1234567890123456789012345678901234567890",
 				ChainID:    1,
@@ -137,7 +135,7 @@ func (s *AlertEvaluatorTestSuite) TestAlertTarget() {
 		},
 		{
 			name: "Pool target",
-			target: AlertTarget{
+			target: models.AlertTarget{
 				Type:       "pool",
 				Identifier: "aave-v3-usdc",
 				ChainID:    0,
@@ -151,7 +149,7 @@ func (s *AlertEvaluatorTestSuite) TestAlertTarget() {
 			targetJSON, err := json.Marshal(tc.target)
 			s.NoError(err)
 
-			var parsed AlertTarget
+			var parsed models.AlertTarget
 			err = json.Unmarshal(targetJSON, &parsed)
 			s.NoError(err)
 			s.Equal(tc.expected, parsed.Type)
@@ -279,6 +277,38 @@ func (s *AlertEvaluatorTestSuite) TestTransferThresholdParsing() {
 	}
 }
 
+// TestTransferExceeds tests transfer thresholds in wei and in USD
+func (s *AlertEvaluatorTestSuite) TestTransferExceeds() {
+	wei := "1000000000000000000" // 1 ETH
+	weiAlert := &models.Alert{Conditions: models.AlertConditions{Threshold: &wei}}
+	usdAlert := &models.Alert{Conditions: models.AlertConditions{ThresholdUSD: floatPtr(5000)}}
+	transfer := Transfer{Amount: "2000000000000000000", ChainID: 1} // 2 ETH
+
+	payload, exceeds, err := transferExceeds(weiAlert, transfer, nil)
+	s.NoError(err)
+	s.True(exceeds)
+	s.Equal(wei, payload["threshold"])
+	s.NotContains(payload, "transferAmountUsd", "a wei threshold doesn't need a price")
+
+	payload, exceeds, err = transferExceeds(usdAlert, transfer, floatPtr(3000))
+	s.NoError(err)
+	s.True(exceeds, "2 ETH at $3000 is above $5000")
+	s.Equal("2000000000000000000", payload["transferAmount"])
+	s.Equal(6000.0, payload["transferAmountUsd"])
+	s.Equal(5000.0, payload["thresholdUsd"])
+	s.Equal(1, payload["chainId"])
+
+	_, exceeds, err = transferExceeds(usdAlert, transfer, floatPtr(2000))
+	s.NoError(err)
+	s.False(exceeds, "2 ETH at $2000 is not above $5000")
+
+	_, _, err = transferExceeds(usdAlert, transfer, nil)
+	s.ErrorIs(err, errNoPrice)
+
+	_, _, err = transferExceeds(usdAlert, Transfer{Amount: "not a number", ChainID: 1}, floatPtr(3000))
+	s.Error(err)
+}
+
 // TestAlertCooldown tests that alerts respect cooldown period
 func (s *AlertEvaluatorTestSuite) TestAlertCooldown() {
 	now := time.Now()
@@ -312,8 +342,8 @@ func (s *AlertEvaluatorTestSuite) TestAlertCooldown() {
 	for _, tc := range testCases {
 		s.Run(tc.name, func() {
 			// Test the cooldown logic used in getActiveAlerts
-			shouldEvaluate := tc.lastTriggered == nil || 
-				tc.lastTriggered.Before(now.Add(-1*time.Hour))
+			shouldEvaluate := tc.lastTriggered == nil ||
+				!tc.lastTriggered.After(now.Add(-1*time.Hour))
 			s.Equal(tc.shouldEvaluate, shouldEvaluate)
 		})
 	}
@@ -323,13 +353,13 @@ func (s *AlertEvaluatorTestSuite) TestAlertCooldown() {
 func (s *AlertEvaluatorTestSuite) TestNotificationPrefs() {
 	testCases := []struct {
 		name     string
-		prefs    NotificationPrefs
+		prefs    models.AlertNotification
 		hasEmail bool
 		hasWebhook bool
 	}{
 		{
 			name: "Email only",
-			prefs: NotificationPrefs{
+			prefs: models.AlertNotification{
 				Email: true,
 			},
 			hasEmail: true,
@@ -337,7 +367,7 @@ func (s *AlertEvaluatorTestSuite) TestNotificationPrefs() {
 		},
 		{
 			name: "Webhook only",
-			prefs: NotificationPrefs{
+			prefs: models.AlertNotification{
 				Email: false,
 				Webhook: "https://example.com/webhook",
 			},
@@ -346,7 +376,7 @@ func (s *AlertEvaluatorTestSuite) TestNotificationPrefs() {
 		},
 		{
 			name: "Both email and webhook",
-			prefs: NotificationPrefs{
+			prefs: models.AlertNotification{
 				Email: true,
 				Webhook: "https://example.com/webhook",
 			},
@@ -355,7 +385,7 @@ func (s *AlertEvaluatorTestSuite) TestNotificationPrefs() {
 		},
 		{
 			name: "Neither",
-			prefs: NotificationPrefs{
+			prefs: models.AlertNotification{
 				Email: false,
 			},
 			hasEmail: false,
@@ -427,4 +457,16 @@ func TestPoolTVLChangeCalculation(t *testing.T) {
 			assert.InDelta(t, tc.expectedPct, changePercent, 0.001)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestAlertEvaluatorCoolingDown(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	job := &AlertEvaluatorJob{clock: fake}
+	triggeredAt := fake.Now().Add(-30 * time.Minute)
+	alert := &models.Alert{LastTriggeredAt: &triggeredAt}
+
+	assert.False(t, job.coolingDown(&models.Alert{}), "alerts that never triggered don't rest")
+	assert.True(t, job.coolingDown(alert))
+	fake.Advance(30 * time.Minute)
+	assert.False(t, job.coolingDown(alert), "an alert rests for an hour after triggering")
+}
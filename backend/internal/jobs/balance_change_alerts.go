@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"math"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// evaluateBalanceChangeAlerts fires for the balance changes detected at the alert's
+// wallet address since it last fired, or since it was created, that move at least the
+// alert's USD amount
+func (j *AlertEvaluatorJob) evaluateBalanceChangeAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	triggered := 0
+	for _, alert := range alerts {
+		since := alert.CreatedAt
+		if alert.LastTriggeredAt != nil && alert.LastTriggeredAt.After(since) {
+			since = *alert.LastTriggeredAt
+		}
+		changes, err := j.balanceRepo.GetChangesSince(ctx, alert.Target.Identifier, alert.Target.ChainID, since)
+		if err != nil {
+			logger.Warn("Failed to get balance changes", "alertId", alert.ID, "error", err)
+			continue
+		}
+
+		triggeredValue := balanceChangeTrigger(alert, changes)
+		if triggeredValue == nil {
+			continue
+		}
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// balanceChangeTrigger lists the changes large enough, and in the direction, the alert
+// asks for, or returns nil when there are none. Changes without a price are skipped.
+func balanceChangeTrigger(alert models.Alert, changes []*models.BalanceChange) map[string]interface{} {
+	if alert.Conditions.ChangeUSD == nil {
+		return nil
+	}
+	threshold := *alert.Conditions.ChangeUSD
+
+	var matched []map[string]interface{}
+	for _, change := range changes {
+		if change.DeltaUSD == nil {
+			continue
+		}
+		moved := *change.DeltaUSD
+		switch alert.Conditions.Direction {
+		case models.BalanceChangeDirectionOut:
+			moved = -moved
+		case models.BalanceChangeDirectionIn:
+		default:
+			moved = math.Abs(moved)
+		}
+		if moved < threshold {
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"walletId":   change.WalletID,
+			"delta":      change.Delta,
+			"deltaUsd":   *change.DeltaUSD,
+			"detectedAt": change.DetectedAt,
+		}
+		if change.Token != nil {
+			entry["tokenSymbol"] = change.Token.Symbol
+			entry["tokenAddress"] = change.Token.Address
+			entry["chainId"] = change.Token.ChainID
+		}
+		matched = append(matched, entry)
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"address":   alert.Target.Identifier,
+		"changeUsd": threshold,
+		"changes":   matched,
+	}
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceChangeTrigger(t *testing.T) {
+	usd := func(v float64) *float64 { return &v }
+	threshold := 10000.0
+	received := &models.BalanceChange{WalletID: uuid.New(), Delta: "15000000000", DeltaUSD: usd(15000), Token: &models.Token{Symbol: "USDC"}}
+	sent := &models.BalanceChange{WalletID: uuid.New(), Delta: "-5000000000000000000", DeltaUSD: usd(-12000), Token: &models.Token{Symbol: "ETH"}}
+	small := &models.BalanceChange{WalletID: uuid.New(), Delta: "1", DeltaUSD: usd(20)}
+	unpriced := &models.BalanceChange{WalletID: uuid.New(), Delta: "1000000000000"}
+	changes := []*models.BalanceChange{received, sent, small, unpriced}
+
+	alert := models.Alert{Target: models.AlertTarget{Type: "address", Identifier: "0xabc"}, Conditions: models.AlertConditions{ChangeUSD: &threshold}}
+	value := balanceChangeTrigger(alert, changes)
+	require.NotNil(t, value)
+	assert.Len(t, value["changes"], 2, "either direction by default")
+
+	alert.Conditions.Direction = models.BalanceChangeDirectionIn
+	value = balanceChangeTrigger(alert, changes)
+	require.NotNil(t, value)
+	matched := value["changes"].([]map[string]interface{})
+	require.Len(t, matched, 1)
+	assert.Equal(t, "USDC", matched[0]["tokenSymbol"])
+
+	alert.Conditions.Direction = models.BalanceChangeDirectionOut
+	matched = balanceChangeTrigger(alert, changes)["changes"].([]map[string]interface{})
+	require.Len(t, matched, 1)
+	assert.Equal(t, -12000.0, matched[0]["deltaUsd"])
+
+	assert.Nil(t, balanceChangeTrigger(alert, []*models.BalanceChange{small, unpriced}))
+}
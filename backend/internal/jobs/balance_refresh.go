@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// balanceRefreshChunkSize is how many wallets are refreshed between saves of progress
+	balanceRefreshChunkSize = 25
+	// balanceRefreshRate paces wallet fetches, each a few provider calls, so a bulk
+	// refresh leaves the provider's throughput to users' own requests
+	balanceRefreshRate = 4
+	// balanceRefreshRunBudget ends a run before the next one is due
+	balanceRefreshRunBudget = 50 * time.Second
+)
+
+// walletBalanceFetcher reads a wallet's balances from its chain, stamped with the block
+// they were read at; *blockchain.BlockchainService in production
+type walletBalanceFetcher interface {
+	GetWalletBalancesAtHead(ctx context.Context, address string, chainID int) ([]*models.Balance, float64, error)
+}
+
+// BalanceRefreshJob works through the balance refreshes admins queue, oldest first, a
+// chunk of wallets at a time. Progress is saved after every chunk so the next run
+// resumes where this one stopped. When the provider throttles, the run ends there and
+// the wallet is retried next run rather than counted as failed. Each balance that
+// changed is published to the wallet's owner as a balance.changed event.
+type BalanceRefreshJob struct {
+	refreshRepo repos.BalanceRefreshRepository
+	balanceRepo repos.BalanceRepository
+	fetcher     walletBalanceFetcher
+	publisher   events.Publisher
+	limiter     *rate.Limiter
+	clock       clock.Clock
+}
+
+func NewBalanceRefreshJob(refreshRepo repos.BalanceRefreshRepository, balanceRepo repos.BalanceRepository, blockchainService *blockchain.BlockchainService, publisher events.Publisher) *BalanceRefreshJob {
+	return &BalanceRefreshJob{
+		refreshRepo: refreshRepo,
+		balanceRepo: balanceRepo,
+		fetcher:     blockchainService,
+		publisher:   publisher,
+		limiter:     rate.NewLimiter(balanceRefreshRate, 1),
+		clock:       clock.System,
+	}
+}
+
+// Run advances the oldest active refresh until it completes, the provider throttles or
+// the run's budget is spent
+func (j *BalanceRefreshJob) Run(ctx context.Context) error {
+	refresh, err := j.refreshRepo.GetNextActive(ctx)
+	if err != nil || refresh == nil {
+		return err
+	}
+	if refresh.Status == models.BalanceRefreshPending {
+		if err := j.refreshRepo.Start(ctx, refresh, j.clock.Now()); err != nil {
+			return err
+		}
+		logger.Info("Starting balance refresh", "refreshId", refresh.ID, "total", refresh.Total)
+	}
+
+	deadline := j.clock.Now().Add(balanceRefreshRunBudget)
+	for j.clock.Now().Before(deadline) {
+		wallets, err := j.refreshRepo.NextWallets(ctx, refresh, balanceRefreshChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(wallets) == 0 {
+			if err := j.refreshRepo.SaveProgress(ctx, refresh, true, j.clock.Now()); err != nil {
+				return err
+			}
+			logger.Info("Balance refresh completed", "refreshId", refresh.ID, "refreshed", refresh.Refreshed, "failed", refresh.Failed)
+			return nil
+		}
+
+		throttled, chunkErr := j.refreshChunk(ctx, refresh, wallets)
+		if err := j.refreshRepo.SaveProgress(ctx, refresh, false, j.clock.Now()); err != nil {
+			return err
+		}
+		if chunkErr != nil {
+			return chunkErr
+		}
+		logger.Info("Balance refresh progress", "refreshId", refresh.ID, "refreshed", refresh.Refreshed, "failed", refresh.Failed, "total", refresh.Total)
+		if throttled {
+			logger.Warn("Balance refresh rate limited, resuming next run", "refreshId", refresh.ID)
+			return nil
+		}
+	}
+	return nil
+}
+
+// refreshChunk refreshes the wallets in order, advancing the refresh's cursor past each
+// one done. It stops at the first wallet the provider throttles, leaving the cursor
+// before it.
+func (j *BalanceRefreshJob) refreshChunk(ctx context.Context, refresh *models.BalanceRefresh, wallets []*models.Wallet) (bool, error) {
+	for _, wallet := range wallets {
+		if err := j.limiter.Wait(ctx); err != nil {
+			return false, err
+		}
+
+		err := j.refreshWallet(ctx, wallet)
+		if errors.Is(err, blockchain.ErrRateLimited) {
+			message := err.Error()
+			refresh.LastError = &message
+			left := refresh.Total - refresh.Refreshed - refresh.Failed
+			QuotaExhausted(ctx, err, fmt.Sprintf("%d wallets of balance refresh %s", left, refresh.ID))
+			return true, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			logger.Warn("Failed to refresh wallet balances", "refreshId", refresh.ID, "walletId", wallet.ID, "chainId", wallet.ChainID, "error", err)
+			message := err.Error()
+			refresh.LastError = &message
+			refresh.Failed++
+		} else {
+			refresh.Refreshed++
+		}
+		id := wallet.ID
+		refresh.Cursor = &id
+	}
+	return false, nil
+}
+
+func (j *BalanceRefreshJob) refreshWallet(ctx context.Context, wallet *models.Wallet) error {
+	balances, _, err := j.fetcher.GetWalletBalancesAtHead(ctx, wallet.Address, wallet.ChainID)
+	if err != nil {
+		return err
+	}
+	changes, err := j.balanceRepo.ReplaceWalletBalances(ctx, wallet.ID, wallet.ChainID, balances)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		event, err := events.NewEvent(events.TypeBalanceChanged, wallet.UserID, change)
+		if err == nil {
+			err = j.publisher.Publish(ctx, event)
+		}
+		if err != nil {
+			logger.Warn("Failed to publish balance change", "walletId", wallet.ID, "tokenId", change.TokenID, "error", err)
+		}
+	}
+	return nil
+}
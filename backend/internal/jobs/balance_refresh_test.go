@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+type memoryBalanceRefreshRepo struct {
+	repos.BalanceRefreshRepository
+	refresh *models.BalanceRefresh
+	wallets []*models.Wallet
+	saves   int
+}
+
+func (r *memoryBalanceRefreshRepo) GetNextActive(ctx context.Context) (*models.BalanceRefresh, error) {
+	if r.refresh.Status == models.BalanceRefreshCompleted {
+		return nil, nil
+	}
+	return r.refresh, nil
+}
+
+func (r *memoryBalanceRefreshRepo) Start(ctx context.Context, refresh *models.BalanceRefresh, at time.Time) error {
+	refresh.Status = models.BalanceRefreshRunning
+	return nil
+}
+
+func (r *memoryBalanceRefreshRepo) NextWallets(ctx context.Context, refresh *models.BalanceRefresh, limit int) ([]*models.Wallet, error) {
+	start := 0
+	if refresh.Cursor != nil {
+		for i, wallet := range r.wallets {
+			if wallet.ID == *refresh.Cursor {
+				start = i + 1
+			}
+		}
+	}
+	end := start + limit
+	if end > len(r.wallets) {
+		end = len(r.wallets)
+	}
+	return r.wallets[start:end], nil
+}
+
+func (r *memoryBalanceRefreshRepo) SaveProgress(ctx context.Context, refresh *models.BalanceRefresh, done bool, at time.Time) error {
+	r.saves++
+	if done {
+		refresh.Status = models.BalanceRefreshCompleted
+	}
+	return nil
+}
+
+type memoryBalanceRepo struct {
+	repos.BalanceRepository
+	stored map[uuid.UUID]int
+}
+
+// ReplaceWalletBalances reports a change the first time a wallet is stored
+func (r *memoryBalanceRepo) ReplaceWalletBalances(ctx context.Context, walletID uuid.UUID, chainID int, balances []*models.Balance) ([]*models.BalanceChange, error) {
+	_, seen := r.stored[walletID]
+	r.stored[walletID] = len(balances)
+	if seen {
+		return nil, nil
+	}
+	return []*models.BalanceChange{{WalletID: walletID, PreviousBalance: "0", NewBalance: "1", Delta: "1"}}, nil
+}
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+// fakeBalanceFetcher fails the addresses listed, with their error
+type fakeBalanceFetcher map[string]error
+
+func (f fakeBalanceFetcher) GetWalletBalancesAtHead(ctx context.Context, address string, chainID int) ([]*models.Balance, float64, error) {
+	if err := f[address]; err != nil {
+		return nil, 0, err
+	}
+	return []*models.Balance{{Balance: "1"}}, 0, nil
+}
+
+func TestBalanceRefreshJob(t *testing.T) {
+	wallets := make([]*models.Wallet, balanceRefreshChunkSize+5)
+	for i := range wallets {
+		wallets[i] = &models.Wallet{ID: uuid.New(), UserID: uuid.New(), Address: fmt.Sprintf("0x%040d", i), ChainID: 1}
+	}
+	refreshRepo := &memoryBalanceRefreshRepo{
+		refresh: &models.BalanceRefresh{ID: uuid.New(), Status: models.BalanceRefreshPending, Total: len(wallets)},
+		wallets: wallets,
+	}
+	balanceRepo := &memoryBalanceRepo{stored: map[uuid.UUID]int{}}
+	publisher := &recordingPublisher{}
+	throttled := wallets[balanceRefreshChunkSize+2]
+	fetcher := fakeBalanceFetcher{
+		wallets[3].Address: fmt.Errorf("alchemy API error: execution reverted"),
+		throttled.Address:  fmt.Errorf("failed to get token balances: %w", blockchain.ErrRateLimited),
+	}
+	job := &BalanceRefreshJob{
+		refreshRepo: refreshRepo,
+		balanceRepo: balanceRepo,
+		fetcher:     fetcher,
+		publisher:   publisher,
+		limiter:     rate.NewLimiter(rate.Inf, 1),
+		clock:       clock.System,
+	}
+	refresh := refreshRepo.refresh
+
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, models.BalanceRefreshRunning, refresh.Status)
+	assert.Equal(t, balanceRefreshChunkSize+1, refresh.Refreshed)
+	assert.Equal(t, 1, refresh.Failed)
+	assert.Equal(t, 2, refreshRepo.saves, "progress is saved after each chunk")
+	require.NotNil(t, refresh.Cursor)
+	assert.Equal(t, wallets[balanceRefreshChunkSize+1].ID, *refresh.Cursor, "the throttled wallet is retried next run")
+	assert.NotContains(t, balanceRepo.stored, wallets[3].ID)
+
+	delete(fetcher, throttled.Address)
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, models.BalanceRefreshCompleted, refresh.Status)
+	assert.Equal(t, len(wallets)-1, refresh.Refreshed)
+	assert.Equal(t, 1, refresh.Failed)
+	assert.Len(t, balanceRepo.stored, len(wallets)-1)
+	require.Len(t, publisher.events, len(wallets)-1, "each change is published")
+	assert.Equal(t, events.TypeBalanceChanged, publisher.events[0].Type)
+	assert.Equal(t, wallets[0].UserID, publisher.events[0].UserID)
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// BillingGraceJob moves subscribers whose failed renewal went unpaid through their grace
+// period back to the free tier
+type BillingGraceJob struct {
+	billingService *services.BillingService
+}
+
+func NewBillingGraceJob(billingService *services.BillingService) *BillingGraceJob {
+	return &BillingGraceJob{billingService: billingService}
+}
+
+func (j *BillingGraceJob) Run(ctx context.Context) error {
+	expired, err := j.billingService.ExpireGracePeriods(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to expire billing grace periods: %w", err)
+	}
+
+	if expired > 0 {
+		logger.Info("Billing grace periods expired", "users", expired)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// BitcoinSyncJob stores the new confirmed transactions of tracked Bitcoin accounts, priced
+// at the day's BTC price for PnL
+type BitcoinSyncJob struct {
+	bitcoinRepo     repos.BitcoinRepository
+	esploraClient   *external.EsploraClient
+	coinGeckoClient *external.CoinGeckoClient
+	clock           clock.Clock
+}
+
+func NewBitcoinSyncJob(bitcoinRepo repos.BitcoinRepository, esploraClient *external.EsploraClient, coinGeckoClient *external.CoinGeckoClient) *BitcoinSyncJob {
+	return &BitcoinSyncJob{
+		bitcoinRepo:     bitcoinRepo,
+		esploraClient:   esploraClient,
+		coinGeckoClient: coinGeckoClient,
+		clock:           clock.System,
+	}
+}
+
+// Run syncs every tracked account, least recently synced first. An account that can't be
+// read is skipped until the next run.
+func (j *BitcoinSyncJob) Run(ctx context.Context) error {
+	accounts, err := j.bitcoinRepo.GetAllAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	// Daily prices are shared across accounts
+	prices := make(map[string]*float64)
+	stored, failed := 0, 0
+	for _, account := range accounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		count, err := j.syncAccount(ctx, account, prices)
+		if err != nil {
+			logger.Error("Failed to sync bitcoin account", "accountID", account.ID, "error", err)
+			failed++
+			continue
+		}
+		stored += count
+	}
+
+	logger.Info("Bitcoin sync completed",
+		"accounts", len(accounts),
+		"transactions", stored,
+		"failed", failed)
+	return nil
+}
+
+func (j *BitcoinSyncJob) syncAccount(ctx context.Context, account *models.BitcoinAccount, prices map[string]*float64) (int, error) {
+	state, err := blockchain.ScanBitcoinAccount(ctx, j.esploraClient, account.Identifier)
+	if err != nil {
+		return 0, err
+	}
+	known, err := j.bitcoinRepo.GetKnownTxids(ctx, account.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	txs, err := blockchain.GetBitcoinTransactions(ctx, j.esploraClient, state.Addresses, known)
+	if err != nil {
+		return 0, err
+	}
+	for _, tx := range txs {
+		tx.PriceUSD = j.dailyPrice(ctx, tx.Timestamp, prices)
+	}
+
+	if err := j.bitcoinRepo.InsertTransactions(ctx, account.ID, txs); err != nil {
+		return 0, err
+	}
+	if err := j.bitcoinRepo.MarkSynced(ctx, account.ID, j.clock.Now()); err != nil {
+		return 0, err
+	}
+	return len(txs), nil
+}
+
+// dailyPrice returns the BTC price on the day of at, or nil when CoinGecko has none. Failed
+// lookups are cached too, so a run doesn't retry the same day.
+func (j *BitcoinSyncJob) dailyPrice(ctx context.Context, at time.Time, prices map[string]*float64) *float64 {
+	day := at.UTC().Format("2006-01-02")
+	if price, ok := prices[day]; ok {
+		return price
+	}
+
+	var price *float64
+	if p, err := j.coinGeckoClient.GetHistoricalPrice(ctx, "bitcoin", at); err != nil {
+		logger.Warn("Failed to get historical BTC price", "day", day, "error", err)
+	} else {
+		price = &p
+	}
+	prices[day] = price
+	return price
+}
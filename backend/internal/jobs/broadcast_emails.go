@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// BroadcastEmailJob sends the emails of admins' broadcasts to their recipients
+type BroadcastEmailJob struct {
+	broadcastService *services.BroadcastService
+}
+
+func NewBroadcastEmailJob(broadcastService *services.BroadcastService) *BroadcastEmailJob {
+	return &BroadcastEmailJob{broadcastService: broadcastService}
+}
+
+// Run sends the broadcast emails not sent yet
+func (j *BroadcastEmailJob) Run(ctx context.Context) error {
+	sent, err := j.broadcastService.SendPendingEmails(ctx)
+	if err != nil {
+		return err
+	}
+	if sent > 0 {
+		logger.Info("Sent broadcast emails", "sent", sent)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// budgetProgressSource works out spending against users' budgets; *services.BudgetService
+// in production
+type budgetProgressSource interface {
+	GetProgress(ctx context.Context, userID uuid.UUID, metric string) (*models.BudgetProgress, error)
+}
+
+// evaluateBudgetAlerts checks each alert owner's spending this month against their
+// budget for the alert's metric. Alerts whose owner has no budget for it never fire.
+func (j *AlertEvaluatorJob) evaluateBudgetAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	type budgetKey struct {
+		userID uuid.UUID
+		metric string
+	}
+	progress := make(map[budgetKey]*models.BudgetProgress)
+
+	triggered := 0
+	for _, alert := range alerts {
+		key := budgetKey{alert.UserID, alert.Conditions.BudgetMetric}
+		p, ok := progress[key]
+		if !ok {
+			var err error
+			p, err = j.budgets.GetProgress(ctx, key.userID, key.metric)
+			if err != nil {
+				logger.Warn("Failed to get budget progress", "alertId", alert.ID, "metric", key.metric, "error", err)
+				continue
+			}
+			progress[key] = p
+		}
+
+		triggeredValue := budgetExceededTrigger(alert, p)
+		if triggeredValue == nil {
+			continue
+		}
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// budgetExceededTrigger returns the trigger payload once spending reaches the alert's
+// share of the budget, or nil when it hasn't, there's no budget or the alert already
+// fired this month
+func budgetExceededTrigger(alert models.Alert, progress *models.BudgetProgress) map[string]interface{} {
+	if progress == nil {
+		return nil
+	}
+	threshold := 100.0
+	if alert.Conditions.BudgetPercent != nil {
+		threshold = *alert.Conditions.BudgetPercent
+	}
+	if progress.Percent < threshold {
+		return nil
+	}
+	if alert.LastTriggeredAt != nil && !alert.LastTriggeredAt.Before(progress.PeriodStart) {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"metric":        progress.Metric,
+		"limitUsd":      progress.LimitUSD,
+		"spentUsd":      progress.SpentUSD,
+		"percent":       progress.Percent,
+		"budgetPercent": threshold,
+		"periodStart":   progress.PeriodStart,
+	}
+}
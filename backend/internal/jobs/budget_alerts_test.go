@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetExceededTrigger(t *testing.T) {
+	periodStart := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	progress := func(spent float64) *models.BudgetProgress {
+		return &models.BudgetProgress{
+			Budget:      &models.Budget{Metric: models.BudgetMetricGasSpend, LimitUSD: 200},
+			PeriodStart: periodStart,
+			PeriodEnd:   periodStart.AddDate(0, 1, 0),
+			SpentUSD:    spent,
+			Percent:     spent / 200 * 100,
+		}
+	}
+	alert := models.Alert{Conditions: models.AlertConditions{BudgetMetric: models.BudgetMetricGasSpend}}
+
+	assert.Nil(t, budgetExceededTrigger(alert, nil), "no budget")
+	assert.Nil(t, budgetExceededTrigger(alert, progress(150)))
+
+	payload := budgetExceededTrigger(alert, progress(250))
+	require.NotNil(t, payload)
+	assert.Equal(t, models.BudgetMetricGasSpend, payload["metric"])
+	assert.Equal(t, 250.0, payload["spentUsd"])
+	assert.Equal(t, 125.0, payload["percent"])
+
+	// Soft limits can warn before the budget is spent
+	early := 75.0
+	alert.Conditions.BudgetPercent = &early
+	assert.NotNil(t, budgetExceededTrigger(alert, progress(150)))
+
+	// Once a month
+	firedThisMonth := periodStart.Add(48 * time.Hour)
+	alert.LastTriggeredAt = &firedThisMonth
+	assert.Nil(t, budgetExceededTrigger(alert, progress(250)))
+	firedLastMonth := periodStart.Add(-time.Hour)
+	alert.LastTriggeredAt = &firedLastMonth
+	assert.NotNil(t, budgetExceededTrigger(alert, progress(250)))
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// checkpointWriteTimeout bounds checkpoint writes, which may run after the job context is cancelled
+const checkpointWriteTimeout = 5 * time.Second
+
+// CheckpointStore persists the last processed cursor per job so interrupted
+// runs resume where they left off instead of starting over
+type CheckpointStore struct {
+	db *pgxpool.Pool
+}
+
+func NewCheckpointStore(db *pgxpool.Pool) *CheckpointStore {
+	return &CheckpointStore{db: db}
+}
+
+// Load returns the saved cursor for a job, or "" if the last run completed
+func (s *CheckpointStore) Load(ctx context.Context, jobName string) (string, error) {
+	var cursor string
+	err := s.db.QueryRow(ctx, `SELECT last_cursor FROM job_checkpoints WHERE job_name = $1`, jobName).Scan(&cursor)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load checkpoint for %s: %w", jobName, err)
+	}
+	return cursor, nil
+}
+
+// Save records progress. It still writes when ctx is already cancelled so the
+// last completed unit of work survives a shutdown.
+func (s *CheckpointStore) Save(ctx context.Context, jobName, cursor string) error {
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkpointWriteTimeout)
+	defer cancel()
+
+	_, err := s.db.Exec(writeCtx, `
+		INSERT INTO job_checkpoints (job_name, last_cursor)
+		VALUES ($1, $2)
+		ON CONFLICT (job_name) DO UPDATE SET last_cursor = EXCLUDED.last_cursor`,
+		jobName, cursor)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint for %s: %w", jobName, err)
+	}
+	return nil
+}
+
+// Clear removes the checkpoint once a run has processed everything
+func (s *CheckpointStore) Clear(ctx context.Context, jobName string) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM job_checkpoints WHERE job_name = $1`, jobName); err != nil {
+		return fmt.Errorf("failed to clear checkpoint for %s: %w", jobName, err)
+	}
+	return nil
+}
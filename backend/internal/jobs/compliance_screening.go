@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// ComplianceScreeningJob screens tracked wallets' counterparties against the sanctions
+// and mixer lists while compliance screening is on
+type ComplianceScreeningJob struct {
+	compliance *services.ComplianceService
+}
+
+func NewComplianceScreeningJob(compliance *services.ComplianceService) *ComplianceScreeningJob {
+	return &ComplianceScreeningJob{compliance: compliance}
+}
+
+// Run rates every wallet's exposure, doing nothing while the feature flag is off
+func (j *ComplianceScreeningJob) Run(ctx context.Context) error {
+	if !j.compliance.Enabled(ctx) {
+		return nil
+	}
+
+	exposed, err := j.compliance.Screen(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("Screened wallet counterparties", "exposed", exposed)
+	return nil
+}
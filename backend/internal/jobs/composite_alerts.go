@@ -0,0 +1,279 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// anyPoolScanLimit caps how many pools an any_pool condition considers, largest TVL first
+const anyPoolScanLimit = 500
+
+// poolSnapshot is the yield pool data composite conditions can reference
+type poolSnapshot struct {
+	PoolID string
+	APY    float64
+	TVLUSD float64
+}
+
+// conditionContext evaluates composite rules, caching lookups across the alerts in a shard
+type conditionContext struct {
+	job         *AlertEvaluatorJob
+	tokenPrices map[tokenKey]float64
+	gasPrices   map[int]float64
+	pools       map[string]*poolSnapshot
+}
+
+func newConditionContext(j *AlertEvaluatorJob) *conditionContext {
+	return &conditionContext{
+		job:         j,
+		tokenPrices: make(map[tokenKey]float64),
+		gasPrices:   make(map[int]float64),
+		pools:       make(map[string]*poolSnapshot),
+	}
+}
+
+// evaluateCompositeAlerts walks each alert's condition tree and triggers the ones that match
+func (j *AlertEvaluatorJob) evaluateCompositeAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	cc := newConditionContext(j)
+	triggered := 0
+
+	for _, alert := range alerts {
+		if alert.Conditions.Rule == nil {
+			logger.Error("Rule missing for composite alert", "alertId", alert.ID)
+			continue
+		}
+
+		observed := make(map[string]interface{})
+		matched, err := cc.evaluate(ctx, alert.Conditions.Rule, alert.Target, nil, observed)
+		if errors.Is(err, errStaleData) {
+			coverageRunFrom(ctx).skippedStale([]models.Alert{alert})
+			continue
+		}
+		if err != nil {
+			logger.Error("Failed to evaluate composite alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		triggeredValue := map[string]interface{}{
+			"observed": observed,
+		}
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// evaluate resolves a node against live data. pool is set inside an any_pool scope.
+// Values read along the way are recorded in observed for the alert history.
+func (cc *conditionContext) evaluate(ctx context.Context, node *models.AlertConditionNode, target models.AlertTarget, pool *poolSnapshot, observed map[string]interface{}) (bool, error) {
+	switch node.Operator {
+	case models.ConditionOperatorAnd:
+		for i := range node.Children {
+			ok, err := cc.evaluate(ctx, &node.Children[i], target, pool, observed)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case models.ConditionOperatorOr:
+		var firstErr error
+		for i := range node.Children {
+			ok, err := cc.evaluate(ctx, &node.Children[i], target, pool, observed)
+			if err != nil {
+				// One branch without data shouldn't block the others
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, firstErr
+
+	case models.ConditionOperatorAnyPool:
+		pools, err := cc.job.getPoolsByFilter(ctx, node.PoolFilter)
+		if err != nil {
+			return false, fmt.Errorf("failed to load pools: %w", err)
+		}
+		for i := range pools {
+			all := models.AlertConditionNode{Operator: models.ConditionOperatorAnd, Children: node.Children}
+			poolObserved := make(map[string]interface{})
+			ok, err := cc.evaluate(ctx, &all, target, &pools[i], poolObserved)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				poolObserved["poolId"] = pools[i].PoolID
+				observed["matchedPool"] = poolObserved
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "":
+		value, key, err := cc.metric(ctx, node, target, pool)
+		if err != nil {
+			return false, err
+		}
+		observed[key] = value
+		return compareCondition(node.Comparator, value, *node.Value), nil
+
+	default:
+		return false, fmt.Errorf("unknown operator: %s", node.Operator)
+	}
+}
+
+// metric returns the current value of a leaf's metric and the key it is reported under
+func (cc *conditionContext) metric(ctx context.Context, node *models.AlertConditionNode, target models.AlertTarget, pool *poolSnapshot) (float64, string, error) {
+	if node.Value == nil {
+		return 0, "", fmt.Errorf("value missing for metric %s", node.Metric)
+	}
+	if node.Target != nil {
+		target = *node.Target
+	}
+
+	switch node.Metric {
+	case models.ConditionMetricTokenPrice:
+		key := newTokenKey(target.Identifier, target.ChainID)
+		price, ok := cc.tokenPrices[key]
+		if !ok {
+			prices, stale, err := cc.job.getTokenPrices(ctx, map[tokenKey][]models.Alert{key: nil})
+			if err != nil {
+				return 0, "", err
+			}
+			if stale[key] {
+				return 0, "", fmt.Errorf("%w: price of token %s on chain %d", errStaleData, target.Identifier, target.ChainID)
+			}
+			if price, ok = prices[key]; !ok {
+				return 0, "", fmt.Errorf("no price for token %s on chain %d", target.Identifier, target.ChainID)
+			}
+			cc.tokenPrices[key] = price
+		}
+		return price, fmt.Sprintf("token_price:%s-%d", target.Identifier, target.ChainID), nil
+
+	case models.ConditionMetricGasPriceGwei:
+		chainID := target.ChainID
+		if chainID == 0 {
+			chainID = 1
+		}
+		gwei, ok := cc.gasPrices[chainID]
+		if !ok {
+			if cc.job.blockchainService == nil {
+				return 0, "", fmt.Errorf("gas price source not configured")
+			}
+			var err error
+			gwei, err = cc.job.blockchainService.GetGasPriceGwei(ctx, chainID)
+			if err != nil {
+				return 0, "", fmt.Errorf("failed to get gas price: %w", err)
+			}
+			cc.gasPrices[chainID] = gwei
+		}
+		return gwei, fmt.Sprintf("gas_price_gwei:%d", chainID), nil
+
+	case models.ConditionMetricPoolAPY, models.ConditionMetricPoolTVLUSD:
+		if pool == nil || node.Target != nil {
+			if target.Type != "pool" {
+				return 0, "", fmt.Errorf("metric %s has no pool target", node.Metric)
+			}
+			var err error
+			pool, err = cc.pool(ctx, target.Identifier)
+			if err != nil {
+				return 0, "", err
+			}
+		}
+		if node.Metric == models.ConditionMetricPoolAPY {
+			return pool.APY, "pool_apy:" + pool.PoolID, nil
+		}
+		return pool.TVLUSD, "pool_tvl_usd:" + pool.PoolID, nil
+
+	default:
+		return 0, "", fmt.Errorf("unknown metric: %s", node.Metric)
+	}
+}
+
+func (cc *conditionContext) pool(ctx context.Context, poolID string) (*poolSnapshot, error) {
+	if p, ok := cc.pools[poolID]; ok {
+		return p, nil
+	}
+
+	p := &poolSnapshot{PoolID: poolID}
+	err := cc.job.db.QueryRow(ctx, `
+		SELECT COALESCE(apy, 0), COALESCE(tvl_usd, 0)
+		FROM yield_pools
+		WHERE pool_id = $1`,
+		poolID).Scan(&p.APY, &p.TVLUSD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool %s: %w", poolID, err)
+	}
+
+	cc.pools[poolID] = p
+	return p, nil
+}
+
+// getPoolsByFilter lists candidate pools for an any_pool condition
+func (j *AlertEvaluatorJob) getPoolsByFilter(ctx context.Context, filter *models.AlertPoolFilter) ([]poolSnapshot, error) {
+	if filter == nil {
+		filter = &models.AlertPoolFilter{}
+	}
+
+	rows, err := j.db.Query(ctx, `
+		SELECT pool_id, COALESCE(apy, 0), COALESCE(tvl_usd, 0)
+		FROM yield_pools
+		WHERE ($1::boolean IS NULL OR stable_coin = $1)
+			AND ($2 = '' OR LOWER(chain) = LOWER($2))
+			AND ($3 = '' OR LOWER(protocol) = LOWER($3))
+			AND COALESCE(tvl_usd, 0) >= $4
+		ORDER BY tvl_usd DESC NULLS LAST
+		LIMIT $5`,
+		filter.StableCoin, filter.Chain, filter.Protocol, filter.MinTVLUSD, anyPoolScanLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pools []poolSnapshot
+	for rows.Next() {
+		var p poolSnapshot
+		if err := rows.Scan(&p.PoolID, &p.APY, &p.TVLUSD); err != nil {
+			return nil, err
+		}
+		pools = append(pools, p)
+	}
+
+	return pools, rows.Err()
+}
+
+// compareCondition applies a leaf comparator
+func compareCondition(comparator string, actual, expected float64) bool {
+	switch comparator {
+	case models.ConditionComparatorGT:
+		return actual > expected
+	case models.ConditionComparatorGTE:
+		return actual >= expected
+	case models.ConditionComparatorLT:
+		return actual < expected
+	case models.ConditionComparatorLTE:
+		return actual <= expected
+	default:
+		return false
+	}
+}
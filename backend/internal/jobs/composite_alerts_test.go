@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareCondition(t *testing.T) {
+	tests := []struct {
+		comparator string
+		actual     float64
+		want       bool
+	}{
+		{models.ConditionComparatorGT, 11, true},
+		{models.ConditionComparatorGT, 10, false},
+		{models.ConditionComparatorGTE, 10, true},
+		{models.ConditionComparatorGTE, 9, false},
+		{models.ConditionComparatorLT, 9, true},
+		{models.ConditionComparatorLT, 10, false},
+		{models.ConditionComparatorLTE, 10, true},
+		{models.ConditionComparatorLTE, 11, false},
+		{"eq", 10, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, compareCondition(tt.comparator, tt.actual, 10), "%s %v", tt.comparator, tt.actual)
+	}
+}
+
+func TestConditionContextEvaluate(t *testing.T) {
+	target := models.AlertTarget{Type: "token", Identifier: "0xabc", ChainID: 1}
+	// Values are cached up front, so evaluation never reaches the database
+	cc := newConditionContext(&AlertEvaluatorJob{})
+	cc.tokenPrices[newTokenKey(target.Identifier, target.ChainID)] = 1800
+	cc.gasPrices[1] = 15
+	cc.pools["pool-1"] = &poolSnapshot{PoolID: "pool-1", APY: 12, TVLUSD: 5e6}
+
+	leaf := func(metric, comparator string, value float64) models.AlertConditionNode {
+		return models.AlertConditionNode{Metric: metric, Comparator: comparator, Value: &value}
+	}
+	group := func(operator string, children ...models.AlertConditionNode) models.AlertConditionNode {
+		return models.AlertConditionNode{Operator: operator, Children: children}
+	}
+	priceBelow2000 := leaf(models.ConditionMetricTokenPrice, models.ConditionComparatorLT, 2000)
+	priceAbove2000 := leaf(models.ConditionMetricTokenPrice, models.ConditionComparatorGT, 2000)
+	gasBelow20 := leaf(models.ConditionMetricGasPriceGwei, models.ConditionComparatorLTE, 20)
+	gasAbove20 := leaf(models.ConditionMetricGasPriceGwei, models.ConditionComparatorGTE, 20)
+	poolAPY := leaf(models.ConditionMetricPoolAPY, models.ConditionComparatorGT, 10)
+	poolAPY.Target = &models.AlertTarget{Type: "pool", Identifier: "pool-1"}
+
+	tests := []struct {
+		name string
+		rule models.AlertConditionNode
+		want bool
+	}{
+		{"leaf", priceBelow2000, true},
+		{"and matches", group(models.ConditionOperatorAnd, priceBelow2000, gasBelow20), true},
+		{"and misses", group(models.ConditionOperatorAnd, priceBelow2000, gasAbove20), false},
+		{"or matches", group(models.ConditionOperatorOr, priceAbove2000, gasBelow20), true},
+		{"or misses", group(models.ConditionOperatorOr, priceAbove2000, gasAbove20), false},
+		{"or inside and", group(models.ConditionOperatorAnd, poolAPY, group(models.ConditionOperatorOr, priceAbove2000, gasBelow20)), true},
+		{"and inside or", group(models.ConditionOperatorOr, group(models.ConditionOperatorAnd, priceAbove2000, gasBelow20), gasAbove20), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observed := make(map[string]interface{})
+			matched, err := cc.evaluate(context.Background(), &tt.rule, target, nil, observed)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+
+	// Observed values are reported for the alert history
+	observed := make(map[string]interface{})
+	rule := group(models.ConditionOperatorAnd, priceBelow2000, poolAPY)
+	_, err := cc.evaluate(context.Background(), &rule, target, nil, observed)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"token_price:0xabc-1": 1800.0, "pool_apy:pool-1": 12.0}, observed)
+
+	// An OR with one branch lacking data still matches on the other
+	unpriced := leaf(models.ConditionMetricPoolTVLUSD, models.ConditionComparatorGT, 0)
+	rule = group(models.ConditionOperatorOr, unpriced, gasBelow20)
+	matched, err := cc.evaluate(context.Background(), &rule, target, nil, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	// pool_* metrics on an alert without a pool target fail rather than query a token as a pool
+	rule = group(models.ConditionOperatorAnd, unpriced)
+	_, err = cc.evaluate(context.Background(), &rule, target, nil, map[string]interface{}{})
+	assert.ErrorContains(t, err, "no pool target")
+}
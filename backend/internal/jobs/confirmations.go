@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	pendingTransactionBatchSize = 500
+	// droppedAfter is how long a transaction the node doesn't know may stay pending,
+	// allowing for propagation delays after broadcast
+	droppedAfter = 15 * time.Minute
+)
+
+// ConfirmationTrackerJob advances pending transactions towards finality. A pending
+// transaction becomes confirmed or failed once its block has the chain's finality
+// threshold of confirmations, or dropped if it left the mempool without being mined.
+type ConfirmationTrackerJob struct {
+	transactionRepo   repos.TransactionRepository
+	blockchainService *blockchain.BlockchainService
+	publisher         events.Publisher
+	clock             clock.Clock
+}
+
+func NewConfirmationTrackerJob(transactionRepo repos.TransactionRepository, blockchainService *blockchain.BlockchainService, publisher events.Publisher) *ConfirmationTrackerJob {
+	return &ConfirmationTrackerJob{
+		transactionRepo:   transactionRepo,
+		blockchainService: blockchainService,
+		publisher:         publisher,
+		clock:             clock.System,
+	}
+}
+
+// TransactionStatusChange is the realtime event payload sent when a pending transaction settles
+type TransactionStatusChange struct {
+	TransactionID string `json:"transaction_id"`
+	Hash          string `json:"hash"`
+	ChainID       int    `json:"chain_id"`
+	Status        string `json:"status"`
+	Confirmations int64  `json:"confirmations"`
+	BlockNumber   *int64 `json:"block_number,omitempty"`
+}
+
+// Run checks every pending transaction once
+func (j *ConfirmationTrackerJob) Run(ctx context.Context) error {
+	pending, err := j.transactionRepo.GetPending(ctx, pendingTransactionBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	heads := make(map[int]int64)
+	settled := 0
+	for _, tx := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		head, ok := heads[tx.ChainID]
+		if !ok {
+			head, err = j.blockchainService.GetLatestBlockNumber(ctx, tx.ChainID)
+			if err != nil {
+				logger.Warn("Failed to get chain head", "chainID", tx.ChainID, "error", err)
+				continue
+			}
+			heads[tx.ChainID] = head
+		}
+
+		changed, err := j.track(ctx, tx, head)
+		if err != nil {
+			logger.Warn("Failed to track transaction confirmations", "hash", tx.Hash, "chainID", tx.ChainID, "error", err)
+			continue
+		}
+		if changed {
+			settled++
+		}
+	}
+
+	logger.Info("Confirmation tracker completed", "pending", len(pending), "settled", settled)
+	return nil
+}
+
+// track updates one pending transaction and reports whether it left the pending state
+func (j *ConfirmationTrackerJob) track(ctx context.Context, tx *models.Transaction, head int64) (bool, error) {
+	receipt, err := j.blockchainService.GetTransactionReceipt(ctx, tx.ChainID, tx.Hash)
+	if err != nil && !errors.Is(err, blockchain.ErrReceiptNotFound) {
+		return false, err
+	}
+
+	var (
+		status        = models.TransactionStatusPending
+		confirmations int64
+		blockNumber   *int64
+		blockHash     *string
+	)
+
+	if receipt == nil {
+		known, err := j.blockchainService.IsTransactionKnown(ctx, tx.ChainID, tx.Hash)
+		if err != nil {
+			return false, err
+		}
+		if !known && j.clock.Now().Sub(tx.CreatedAt) > droppedAfter {
+			status = models.TransactionStatusDropped
+		}
+	} else {
+		blockNumber = &receipt.BlockNumber
+		blockHash = &receipt.BlockHash
+		status, confirmations = confirmationStatus(receipt, head, blockchain.FinalityThreshold(tx.ChainID))
+	}
+
+	if status == tx.Status && confirmations == tx.Confirmations {
+		return false, nil
+	}
+	if err := j.transactionRepo.UpdateConfirmations(ctx, tx.ID, tx.ChainID, status, confirmations, blockNumber, blockHash); err != nil {
+		return false, err
+	}
+	if status == models.TransactionStatusPending {
+		return false, nil
+	}
+
+	j.publish(ctx, tx, TransactionStatusChange{
+		TransactionID: tx.ID.String(),
+		Hash:          tx.Hash,
+		ChainID:       tx.ChainID,
+		Status:        status,
+		Confirmations: confirmations,
+		BlockNumber:   blockNumber,
+	})
+	return true, nil
+}
+
+// confirmationStatus counts the confirmations of a mined transaction and settles it
+// as confirmed or failed once the threshold is reached
+func confirmationStatus(receipt *blockchain.TransactionReceipt, head, threshold int64) (string, int64) {
+	confirmations := head - receipt.BlockNumber + 1
+	if confirmations < 0 {
+		// Load-balanced nodes can lag each other by a block or two
+		confirmations = 0
+	}
+	if confirmations < threshold {
+		return models.TransactionStatusPending, confirmations
+	}
+	if receipt.Status == "failed" {
+		return models.TransactionStatusFailed, confirmations
+	}
+	return models.TransactionStatusConfirmed, confirmations
+}
+
+func (j *ConfirmationTrackerJob) publish(ctx context.Context, tx *models.Transaction, change TransactionStatusChange) {
+	eventType := map[string]string{
+		models.TransactionStatusConfirmed: events.TypeTransactionConfirmed,
+		models.TransactionStatusFailed:    events.TypeTransactionFailed,
+		models.TransactionStatusDropped:   events.TypeTransactionDropped,
+	}[change.Status]
+
+	userIDs, err := j.transactionRepo.GetOwnerIDs(ctx, tx.ID, tx.ChainID)
+	if err != nil {
+		logger.Warn("Failed to get transaction owners", "hash", tx.Hash, "error", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		event, err := events.NewEvent(eventType, userID, change)
+		if err == nil {
+			err = j.publisher.Publish(ctx, event)
+		}
+		if err != nil {
+			logger.Warn("Failed to publish transaction status change", "type", eventType, "hash", tx.Hash, "userID", userID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmationStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		receiptStatus string
+		head          int64
+		status        string
+		confirmations int64
+	}{
+		{"just mined", "success", 100, models.TransactionStatusPending, 1},
+		{"below threshold", "success", 110, models.TransactionStatusPending, 11},
+		{"reaches threshold", "success", 111, models.TransactionStatusConfirmed, 12},
+		{"reverted at threshold", "failed", 120, models.TransactionStatusFailed, 21},
+		{"head behind receipt", "success", 98, models.TransactionStatusPending, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := &blockchain.TransactionReceipt{BlockNumber: 100, Status: tt.receiptStatus}
+			status, confirmations := confirmationStatus(receipt, tt.head, 12)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.confirmations, confirmations)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// contractMetadataBatchSize caps the contracts looked up per run; each takes up to
+	// three calls
+	contractMetadataBatchSize = 25
+	// contractMetadataMaxAge is how long a lookup is trusted; proxies can be upgraded
+	contractMetadataMaxAge = 7 * 24 * time.Hour
+	// contractMetadataSource names the explorer contracts are looked up on
+	contractMetadataSource = "etherscan"
+)
+
+// ContractExplorer reads contracts' source verification and deployment from a chain
+// explorer
+type ContractExplorer interface {
+	GetSourceCode(ctx context.Context, chainID int, address string) (*external.ContractSource, error)
+	GetContractCreation(ctx context.Context, chainID int, address string) (*external.ContractCreation, error)
+}
+
+// ContractMetadataJob looks up whether held tokens' and allowance spenders' contracts
+// are verified, whether they're proxies and for what, and when they were deployed
+type ContractMetadataJob struct {
+	contractRepo repos.ContractRepository
+	explorer     ContractExplorer
+}
+
+func NewContractMetadataJob(contractRepo repos.ContractRepository, explorer ContractExplorer) *ContractMetadataJob {
+	return &ContractMetadataJob{
+		contractRepo: contractRepo,
+		explorer:     explorer,
+	}
+}
+
+// Run looks up a batch of contracts never looked up or looked up too long ago
+func (j *ContractMetadataJob) Run(ctx context.Context) error {
+	refs, err := j.contractRepo.GetStale(ctx, contractMetadataMaxAge, contractMetadataBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	looked := 0
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info, err := j.lookup(ctx, ref)
+		if err != nil {
+			logger.Warn("Failed to look up contract", "address", ref.Address, "chainID", ref.ChainID, "error", err)
+			continue
+		}
+		if err := j.contractRepo.Upsert(ctx, info); err != nil {
+			logger.Error("Failed to store contract", "address", ref.Address, "error", err)
+			continue
+		}
+		looked++
+	}
+
+	logger.Info("Contract lookup completed", "contracts", len(refs), "lookedUp", looked)
+	return nil
+}
+
+// lookup reads a contract's deployment and source, and for proxies whether the
+// implementation's source is verified too
+func (j *ContractMetadataJob) lookup(ctx context.Context, ref models.ContractRef) (*models.ContractInfo, error) {
+	info := &models.ContractInfo{ChainID: ref.ChainID, Address: ref.Address, Source: contractMetadataSource}
+
+	creation, err := j.explorer.GetContractCreation(ctx, ref.ChainID, ref.Address)
+	if errors.Is(err, external.ErrEtherscanNoData) {
+		// No deployment: a plain wallet, which has no source either
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.IsContract = true
+	info.Creator = optionalString(creation.Creator)
+	info.CreationTxHash = optionalString(creation.TxHash)
+	if deployedAt, ok := creation.DeployedAt(); ok {
+		info.DeployedAt = &deployedAt
+	}
+
+	source, err := j.explorer.GetSourceCode(ctx, ref.ChainID, ref.Address)
+	if err != nil {
+		return nil, err
+	}
+	info.Verified = source.Verified()
+	info.Name = optionalString(source.ContractName)
+	info.IsProxy = source.IsProxy()
+	if info.IsProxy && source.Implementation != "" {
+		info.Implementation = &source.Implementation
+		implementation, err := j.explorer.GetSourceCode(ctx, ref.ChainID, source.Implementation)
+		if err != nil {
+			return nil, err
+		}
+		verified := implementation.Verified()
+		info.ImplementationVerified = &verified
+	}
+	return info, nil
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryContractRepo struct {
+	repos.ContractRepository
+	stale  []models.ContractRef
+	stored map[string]*models.ContractInfo
+}
+
+func (r *memoryContractRepo) GetStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.ContractRef, error) {
+	return r.stale, nil
+}
+
+func (r *memoryContractRepo) Upsert(ctx context.Context, info *models.ContractInfo) error {
+	r.stored[info.Address] = info
+	return nil
+}
+
+// fakeContractExplorer serves sources and deployments by address; addresses without a
+// deployment aren't contracts
+type fakeContractExplorer struct {
+	sources   map[string]*external.ContractSource
+	creations map[string]*external.ContractCreation
+}
+
+func (e *fakeContractExplorer) GetSourceCode(ctx context.Context, chainID int, address string) (*external.ContractSource, error) {
+	if source, ok := e.sources[address]; ok {
+		return source, nil
+	}
+	return &external.ContractSource{}, nil
+}
+
+func (e *fakeContractExplorer) GetContractCreation(ctx context.Context, chainID int, address string) (*external.ContractCreation, error) {
+	if creation, ok := e.creations[address]; ok {
+		return creation, nil
+	}
+	return nil, external.ErrEtherscanNoData
+}
+
+func TestContractMetadataJobResolvesProxies(t *testing.T) {
+	repo := &memoryContractRepo{
+		stale:  []models.ContractRef{{ChainID: 1, Address: "0xproxy"}, {ChainID: 1, Address: "0xwallet"}},
+		stored: map[string]*models.ContractInfo{},
+	}
+	explorer := &fakeContractExplorer{
+		sources: map[string]*external.ContractSource{
+			"0xproxy": {SourceCode: "contract Proxy {}", ContractName: "Proxy", Proxy: "1", Implementation: "0ximpl"},
+		},
+		creations: map[string]*external.ContractCreation{
+			"0xproxy": {Creator: "0xdeployer", TxHash: "0xhash", Timestamp: "1700000000"},
+		},
+	}
+
+	require.NoError(t, NewContractMetadataJob(repo, explorer).Run(context.Background()))
+
+	proxy := repo.stored["0xproxy"]
+	require.NotNil(t, proxy)
+	assert.True(t, proxy.IsContract)
+	assert.True(t, proxy.Verified)
+	assert.True(t, proxy.IsProxy)
+	require.NotNil(t, proxy.Implementation)
+	assert.Equal(t, "0ximpl", *proxy.Implementation)
+	require.NotNil(t, proxy.ImplementationVerified)
+	assert.False(t, *proxy.ImplementationVerified, "the implementation's source wasn't verified")
+	require.NotNil(t, proxy.DeployedAt)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), *proxy.DeployedAt)
+
+	wallet := repo.stored["0xwallet"]
+	require.NotNil(t, wallet)
+	assert.False(t, wallet.IsContract)
+	assert.False(t, wallet.Verified)
+}
@@ -0,0 +1,321 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// fundingBackfillWindow is how far back funding is fetched for a wallet with none stored
+	fundingBackfillWindow = 90 * 24 * time.Hour
+	// gmxMaintenanceMargin approximates GMX v2's min collateral factor (0.5-1% per market)
+	// when estimating liquidation prices
+	gmxMaintenanceMargin = 0.01
+)
+
+// DerivativePositionSyncJob mirrors tracked wallets' perpetual positions on GMX v2 and
+// Hyperliquid, and records their Hyperliquid funding settlements
+type DerivativePositionSyncJob struct {
+	positionRepo      repos.DerivativePositionRepository
+	blockchainService *blockchain.BlockchainService
+	gmxClient         *external.GMXClient
+	hyperliquidClient *external.HyperliquidClient
+	clock             clock.Clock
+}
+
+func NewDerivativePositionSyncJob(positionRepo repos.DerivativePositionRepository, blockchainService *blockchain.BlockchainService, gmxClient *external.GMXClient, hyperliquidClient *external.HyperliquidClient) *DerivativePositionSyncJob {
+	return &DerivativePositionSyncJob{
+		positionRepo:      positionRepo,
+		blockchainService: blockchainService,
+		gmxClient:         gmxClient,
+		hyperliquidClient: hyperliquidClient,
+		clock:             clock.System,
+	}
+}
+
+// Run syncs every tracked address. A venue that can't be read for an address is skipped,
+// leaving its stored positions as they were rather than closing them.
+func (j *DerivativePositionSyncJob) Run(ctx context.Context) error {
+	addresses, err := j.positionRepo.GetTrackedAddresses(ctx)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	// GMX markets and prices are shared by every address
+	gmxMarkets, gmxTokens, gmxErr := j.gmxReferenceData(ctx)
+	if gmxErr != nil {
+		logger.Warn("Skipping GMX positions, market data unavailable", "error", gmxErr)
+	}
+
+	positions, funding, failed := 0, int64(0), 0
+	for _, address := range addresses {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		synced, stored, err := j.syncHyperliquid(ctx, address)
+		if err != nil {
+			logger.Error("Failed to sync Hyperliquid positions", "address", address, "error", err)
+			failed++
+		}
+		positions += synced
+		funding += stored
+
+		if gmxErr != nil {
+			continue
+		}
+		synced, err = j.syncGMX(ctx, address, gmxMarkets, gmxTokens)
+		if err != nil {
+			logger.Error("Failed to sync GMX positions", "address", address, "error", err)
+			failed++
+		}
+		positions += synced
+	}
+
+	logger.Info("Derivative position sync completed",
+		"addresses", len(addresses),
+		"openPositions", positions,
+		"fundingPayments", funding,
+		"failed", failed)
+	return nil
+}
+
+// SyncAddress syncs one address's positions on every venue, as Run does for all of them
+func (j *DerivativePositionSyncJob) SyncAddress(ctx context.Context, address string) error {
+	if _, _, err := j.syncHyperliquid(ctx, address); err != nil {
+		return fmt.Errorf("failed to sync Hyperliquid positions: %w", err)
+	}
+
+	markets, tokens, err := j.gmxReferenceData(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := j.syncGMX(ctx, address, markets, tokens); err != nil {
+		return fmt.Errorf("failed to sync GMX positions: %w", err)
+	}
+	return nil
+}
+
+func (j *DerivativePositionSyncJob) gmxReferenceData(ctx context.Context) (map[string]*external.GMXMarket, map[string]*external.GMXToken, error) {
+	markets, err := j.gmxClient.GetMarkets(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get GMX markets: %w", err)
+	}
+	tokens, err := j.gmxClient.GetTokens(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get GMX tokens: %w", err)
+	}
+	return markets, tokens, nil
+}
+
+func (j *DerivativePositionSyncJob) syncHyperliquid(ctx context.Context, address string) (int, int64, error) {
+	raw, err := j.hyperliquidClient.GetPositions(ctx, address)
+	if err != nil {
+		return 0, 0, err
+	}
+	positions := make([]*models.DerivativePosition, len(raw))
+	for i := range raw {
+		positions[i] = hyperliquidPosition(&raw[i])
+	}
+	if err := j.positionRepo.SyncPositions(ctx, address, models.DerivativeVenueHyperliquid, positions); err != nil {
+		return 0, 0, err
+	}
+
+	since := j.clock.Now().Add(-fundingBackfillWindow)
+	latest, err := j.positionRepo.GetLatestFundingTime(ctx, address, models.DerivativeVenueHyperliquid)
+	if err != nil {
+		return len(positions), 0, err
+	}
+	if latest != nil {
+		since = latest.Add(time.Millisecond)
+	}
+
+	entries, err := j.hyperliquidClient.GetFunding(ctx, address, since)
+	if err != nil {
+		return len(positions), 0, fmt.Errorf("failed to get funding: %w", err)
+	}
+	stored, err := j.positionRepo.InsertFundingPayments(ctx, hyperliquidFundingPayments(address, entries))
+	return len(positions), stored, err
+}
+
+func (j *DerivativePositionSyncJob) syncGMX(ctx context.Context, address string, markets map[string]*external.GMXMarket, tokens map[string]*external.GMXToken) (int, error) {
+	raw, err := j.blockchainService.GetGMXPositions(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+
+	positions := make([]*models.DerivativePosition, 0, len(raw))
+	for _, p := range raw {
+		position, ok := gmxPosition(p, markets, tokens)
+		if !ok {
+			// Without a price the position can't be valued; keep the stored one rather than
+			// closing it, so the sync is skipped for this address
+			return 0, fmt.Errorf("no price for GMX market %s", p.Market)
+		}
+		positions = append(positions, position)
+	}
+
+	if err := j.positionRepo.SyncPositions(ctx, address, models.DerivativeVenueGMXV2, positions); err != nil {
+		return 0, err
+	}
+	return len(positions), nil
+}
+
+// hyperliquidPosition converts a clearinghouse position. Hyperliquid reports funding as
+// paid by the position, so its sign is flipped to the received-positive convention.
+func hyperliquidPosition(p *external.HyperliquidPosition) *models.DerivativePosition {
+	size := math.Abs(float64(p.Size))
+	side := models.DerivativeSideLong
+	if p.Size < 0 {
+		side = models.DerivativeSideShort
+	}
+
+	position := &models.DerivativePosition{
+		Venue:            models.DerivativeVenueHyperliquid,
+		Market:           p.Coin,
+		Symbol:           p.Coin,
+		Side:             side,
+		Size:             size,
+		SizeUSD:          float64(p.PositionValue),
+		EntryPrice:       float64(p.EntryPrice),
+		CollateralUSD:    float64(p.MarginUsed),
+		UnrealizedPnLUSD: float64(p.UnrealizedPnL),
+		IsOpen:           true,
+	}
+	if size > 0 {
+		mark := float64(p.PositionValue) / size
+		position.MarkPrice = &mark
+	}
+	if p.LiquidationPrice != nil && *p.LiquidationPrice > 0 {
+		liquidation := float64(*p.LiquidationPrice)
+		position.LiquidationPrice = &liquidation
+	}
+	if p.Leverage.Value > 0 {
+		leverage := p.Leverage.Value
+		position.Leverage = &leverage
+	}
+	funding := -float64(p.CumFunding.SinceOpen)
+	position.FundingSinceOpenUSD = &funding
+
+	return position
+}
+
+func hyperliquidFundingPayments(address string, entries []external.HyperliquidFunding) []*models.FundingPayment {
+	payments := make([]*models.FundingPayment, len(entries))
+	for i, entry := range entries {
+		rate := float64(entry.Delta.FundingRate)
+		size := float64(entry.Delta.Size)
+		payments[i] = &models.FundingPayment{
+			WalletAddress: address,
+			Venue:         models.DerivativeVenueHyperliquid,
+			Market:        entry.Delta.Coin,
+			AmountUSD:     float64(entry.Delta.USDC),
+			FundingRate:   &rate,
+			PositionSize:  &size,
+			PaidAt:        time.UnixMilli(entry.Time),
+		}
+	}
+	return payments
+}
+
+// gmxPosition values a raw GMX v2 position at oracle mid prices. Funding and borrowing
+// fees accrue against collateral on GMX and aren't reported per position, so funding is
+// left unset and the liquidation price ignores pending fees.
+func gmxPosition(p *blockchain.GMXPosition, markets map[string]*external.GMXMarket, tokens map[string]*external.GMXToken) (*models.DerivativePosition, bool) {
+	market, ok := markets[strings.ToLower(p.Market)]
+	if !ok {
+		return nil, false
+	}
+	index, ok := tokens[strings.ToLower(market.IndexToken)]
+	if !ok || index.MidPrice() <= 0 {
+		return nil, false
+	}
+	collateral, ok := tokens[strings.ToLower(p.CollateralToken)]
+	if !ok || collateral.MidPrice() <= 0 {
+		return nil, false
+	}
+
+	size := p.Size(index.Decimals)
+	openUSD := p.SizeUSD()
+	mark := index.MidPrice()
+	notional := size * mark
+	collateralAmount := p.Collateral(collateral.Decimals)
+
+	chainID := blockchain.ChainIDArbitrum
+	position := &models.DerivativePosition{
+		Venue:         models.DerivativeVenueGMXV2,
+		ChainID:       &chainID,
+		Market:        strings.ToLower(p.Market),
+		Symbol:        index.Symbol,
+		Side:          models.DerivativeSideShort,
+		Size:          size,
+		SizeUSD:       notional,
+		MarkPrice:     &mark,
+		CollateralUSD: collateralAmount * collateral.MidPrice(),
+		IsOpen:        true,
+	}
+	if p.IsLong {
+		position.Side = models.DerivativeSideLong
+		position.UnrealizedPnLUSD = notional - openUSD
+	} else {
+		position.UnrealizedPnLUSD = openUSD - notional
+	}
+	if size > 0 {
+		position.EntryPrice = openUSD / size
+	}
+	if position.CollateralUSD > 0 {
+		leverage := openUSD / position.CollateralUSD
+		position.Leverage = &leverage
+	}
+
+	// Collateral in the index token moves with the price, other collateral doesn't
+	sameToken := strings.EqualFold(p.CollateralToken, market.IndexToken)
+	if liquidation, ok := gmxLiquidationPrice(p.IsLong, size, openUSD, collateralAmount, position.CollateralUSD, sameToken); ok {
+		position.LiquidationPrice = &liquidation
+	}
+
+	return position, true
+}
+
+// gmxLiquidationPrice solves for the index price at which collateral plus PnL falls to
+// the maintenance margin. With index-token collateral its value is collateralAmount * P;
+// otherwise it is fixed at collateralUSD.
+func gmxLiquidationPrice(isLong bool, size, openUSD, collateralAmount, collateralUSD float64, sameToken bool) (float64, bool) {
+	if size <= 0 {
+		return 0, false
+	}
+
+	var price float64
+	switch {
+	case isLong && sameToken:
+		price = openUSD * (1 + gmxMaintenanceMargin) / (collateralAmount + size)
+	case isLong:
+		price = (openUSD*(1+gmxMaintenanceMargin) - collateralUSD) / size
+	case sameToken:
+		if size <= collateralAmount {
+			// The collateral gains as much as the short loses; it can't be liquidated by price
+			return 0, false
+		}
+		price = openUSD * (1 - gmxMaintenanceMargin) / (size - collateralAmount)
+	default:
+		price = (openUSD*(1-gmxMaintenanceMargin) + collateralUSD) / size
+	}
+
+	if price <= 0 {
+		return 0, false
+	}
+	return price, true
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHyperliquidPosition(t *testing.T) {
+	var raw external.HyperliquidPosition
+	err := json.Unmarshal([]byte(`{
+		"coin": "ETH",
+		"szi": "-2.5",
+		"entryPx": "3000.0",
+		"positionValue": "7250.0",
+		"unrealizedPnl": "250.0",
+		"liquidationPx": "3900.5",
+		"marginUsed": "725.0",
+		"leverage": {"type": "cross", "value": 10},
+		"cumFunding": {"allTime": "40.0", "sinceOpen": "12.5", "sinceChange": "1.0"}
+	}`), &raw)
+	require.NoError(t, err)
+
+	position := hyperliquidPosition(&raw)
+	assert.Equal(t, models.DerivativeSideShort, position.Side)
+	assert.Equal(t, "ETH", position.Market)
+	assert.InDelta(t, 2.5, position.Size, 1e-9)
+	assert.InDelta(t, 7250.0, position.SizeUSD, 1e-9)
+	assert.InDelta(t, 2900.0, *position.MarkPrice, 1e-9)
+	assert.InDelta(t, 3900.5, *position.LiquidationPrice, 1e-9)
+	assert.InDelta(t, 10.0, *position.Leverage, 1e-9)
+	// Funding paid by the position is reported as negative
+	assert.InDelta(t, -12.5, *position.FundingSinceOpenUSD, 1e-9)
+
+	// A null liquidation price (well-collateralized cross position) stays unset
+	err = json.Unmarshal([]byte(`{"coin": "BTC", "szi": "0.1", "entryPx": "60000", "positionValue": "6100", "liquidationPx": null}`), &raw)
+	require.NoError(t, err)
+	position = hyperliquidPosition(&raw)
+	assert.Equal(t, models.DerivativeSideLong, position.Side)
+	assert.Nil(t, position.LiquidationPrice)
+}
+
+func TestGMXPosition(t *testing.T) {
+	usdc := "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	weth := "0x82aF49447D8a07e3bd95BD0d56f35241523fBab1"
+	markets := map[string]*external.GMXMarket{
+		"0x70d95587d40a2caf56bd97485ab3eec10bee6336": {IndexToken: weth, LongToken: weth, ShortToken: usdc},
+	}
+	tokens := map[string]*external.GMXToken{
+		"0xaf88d065e77c8cc2239327c5edb3a432268e5831": {Symbol: "USDC", Decimals: 6, MinPrice: 1, MaxPrice: 1},
+		"0x82af49447d8a07e3bd95bd0d56f35241523fbab1": {Symbol: "ETH", Decimals: 18, MinPrice: 2990, MaxPrice: 3010},
+	}
+	usd30 := new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)
+
+	// 10x long of 4 ETH opened at 2500 with 1000 USDC collateral, marked at 3000
+	raw := &blockchain.GMXPosition{
+		Market:           "0x70d95587d40A2caf56bd97485aB3Eec10Bee6336",
+		CollateralToken:  usdc,
+		SizeInUSD:        new(big.Int).Mul(big.NewInt(10_000), usd30),
+		SizeInTokens:     new(big.Int).Mul(big.NewInt(4), big.NewInt(1e18)),
+		CollateralAmount: big.NewInt(1_000e6),
+		IsLong:           true,
+	}
+	position, ok := gmxPosition(raw, markets, tokens)
+	require.True(t, ok)
+	assert.Equal(t, "ETH", position.Symbol)
+	assert.Equal(t, models.DerivativeSideLong, position.Side)
+	assert.InDelta(t, 2500.0, position.EntryPrice, 1e-9)
+	assert.InDelta(t, 12_000.0, position.SizeUSD, 1e-9)
+	assert.InDelta(t, 2000.0, position.UnrealizedPnLUSD, 1e-9)
+	assert.InDelta(t, 3000.0, position.EquityUSD(), 1e-9)
+	assert.InDelta(t, 10.0, *position.Leverage, 1e-9)
+	// (10000 * 1.01 - 1000) / 4
+	assert.InDelta(t, 2275.0, *position.LiquidationPrice, 1e-9)
+	assert.Nil(t, position.FundingSinceOpenUSD)
+
+	// Unknown markets can't be valued
+	raw.Market = "0x0000000000000000000000000000000000000001"
+	_, ok = gmxPosition(raw, markets, tokens)
+	assert.False(t, ok)
+}
+
+func TestGMXLiquidationPrice(t *testing.T) {
+	// Short of 4 at 2500 with 1000 USD of stable collateral: (10000 * 0.99 + 1000) / 4
+	price, ok := gmxLiquidationPrice(false, 4, 10_000, 1000, 1000, false)
+	require.True(t, ok)
+	assert.InDelta(t, 2725.0, price, 1e-9)
+
+	// Long with 0.4 ETH as collateral: 10000 * 1.01 / 4.4
+	price, ok = gmxLiquidationPrice(true, 4, 10_000, 0.4, 1200, true)
+	require.True(t, ok)
+	assert.InDelta(t, 2295.4545, price, 1e-4)
+
+	// A short fully backed by index-token collateral has no liquidation price
+	_, ok = gmxLiquidationPrice(false, 1, 2500, 1, 3000, true)
+	assert.False(t, ok)
+
+	// Overcollateralized long
+	_, ok = gmxLiquidationPrice(true, 1, 2500, 3000, 3000, false)
+	assert.False(t, ok)
+}
+
+func TestHyperliquidFundingPayments(t *testing.T) {
+	var entries []external.HyperliquidFunding
+	err := json.Unmarshal([]byte(`[
+		{"time": 1700000000000, "hash": "0x0", "delta": {"type": "funding", "coin": "ETH", "usdc": "-3.25", "szi": "2.0", "fundingRate": "0.0000125"}}
+	]`), &entries)
+	require.NoError(t, err)
+
+	payments := hyperliquidFundingPayments("0xabc", entries)
+	require.Len(t, payments, 1)
+	assert.Equal(t, "ETH", payments[0].Market)
+	assert.InDelta(t, -3.25, payments[0].AmountUSD, 1e-9)
+	assert.InDelta(t, 0.0000125, *payments[0].FundingRate, 1e-12)
+	assert.Equal(t, int64(1700000000000), payments[0].PaidAt.UnixMilli())
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// EscalationJob sends the next step of team escalations nobody has acknowledged in time
+type EscalationJob struct {
+	escalations *services.EscalationService
+}
+
+func NewEscalationJob(escalations *services.EscalationService) *EscalationJob {
+	return &EscalationJob{escalations: escalations}
+}
+
+// Run advances every escalation whose next step is due
+func (j *EscalationJob) Run(ctx context.Context) error {
+	advanced, err := j.escalations.AdvanceDue(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to advance escalations: %w", err)
+	}
+
+	if advanced > 0 {
+		logger.Info("Advanced alert escalations", "count", advanced)
+	}
+	return nil
+}
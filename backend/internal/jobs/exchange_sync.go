@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// exchangeSyncOverlap re-reads trades from shortly before the last sync, so fills the
+// exchange reported late aren't missed; trades already stored are skipped
+const exchangeSyncOverlap = time.Hour
+
+// ExchangeSyncJob refreshes connected exchange accounts' balances and stores their new
+// trades, with the quote asset priced in USD on the day of each trade for PnL
+type ExchangeSyncJob struct {
+	exchangeRepo    repos.ExchangeRepository
+	exchangeService *services.ExchangeService
+	coinGeckoClient *external.CoinGeckoClient
+	clock           clock.Clock
+}
+
+func NewExchangeSyncJob(exchangeRepo repos.ExchangeRepository, exchangeService *services.ExchangeService, coinGeckoClient *external.CoinGeckoClient) *ExchangeSyncJob {
+	return &ExchangeSyncJob{
+		exchangeRepo:    exchangeRepo,
+		exchangeService: exchangeService,
+		coinGeckoClient: coinGeckoClient,
+		clock:           clock.System,
+	}
+}
+
+// Run syncs every connected account, least recently synced first. A failed account keeps
+// its error for the user to see and is retried on the next run.
+func (j *ExchangeSyncJob) Run(ctx context.Context) error {
+	accounts, err := j.exchangeRepo.GetAllAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	// Daily prices are shared across accounts, keyed by CoinGecko ID and day
+	prices := make(map[string]*float64)
+	stored, failed := 0, 0
+	for _, account := range accounts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		syncedAt := j.clock.Now()
+		count, err := j.syncAccount(ctx, account, prices)
+		var syncErr *string
+		if err != nil {
+			logger.Error("Failed to sync exchange account", "accountID", account.ID, "exchange", account.Exchange, "error", err)
+			message := err.Error()
+			syncErr = &message
+			failed++
+		}
+		if err := j.exchangeRepo.MarkSynced(ctx, account.ID, syncedAt, syncErr); err != nil {
+			logger.Error("Failed to record exchange account sync", "accountID", account.ID, "error", err)
+		}
+		stored += count
+	}
+
+	logger.Info("Exchange sync completed",
+		"accounts", len(accounts),
+		"trades", stored,
+		"failed", failed)
+	return nil
+}
+
+func (j *ExchangeSyncJob) syncAccount(ctx context.Context, account *models.ExchangeAccount, prices map[string]*float64) (int, error) {
+	connector, err := j.exchangeService.Connector(account)
+	if err != nil {
+		return 0, err
+	}
+
+	balances, err := connector.GetBalances(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := j.exchangeRepo.ReplaceBalances(ctx, account.ID, services.ExchangeAssetBalances(balances)); err != nil {
+		return 0, err
+	}
+
+	var since time.Time
+	if account.LastSyncedAt != nil {
+		since = account.LastSyncedAt.Add(-exchangeSyncOverlap)
+	}
+	fills, err := connector.GetTrades(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	trades := make([]*models.ExchangeTrade, len(fills))
+	for i, fill := range fills {
+		trades[i] = &models.ExchangeTrade{
+			TradeID:       fill.TradeID,
+			BaseAsset:     fill.BaseAsset,
+			QuoteAsset:    fill.QuoteAsset,
+			Side:          fill.Side,
+			Quantity:      fill.Quantity,
+			Price:         fill.Price,
+			Fee:           fill.Fee,
+			FeeAsset:      fill.FeeAsset,
+			QuotePriceUSD: j.quotePrice(ctx, fill.QuoteAsset, fill.ExecutedAt, prices),
+			ExecutedAt:    fill.ExecutedAt,
+		}
+	}
+	if err := j.exchangeRepo.InsertTrades(ctx, account.ID, trades); err != nil {
+		return 0, err
+	}
+	return len(trades), nil
+}
+
+// quotePrice returns a quote asset's USD price on the day of at: one for dollars and
+// stablecoins, CoinGecko's daily price otherwise, or nil when neither is known
+func (j *ExchangeSyncJob) quotePrice(ctx context.Context, asset string, at time.Time, prices map[string]*float64) *float64 {
+	if external.IsUSDAsset(asset) {
+		one := 1.0
+		return &one
+	}
+	coinID, ok := external.TokenIDMappings[strings.ToLower(asset)]
+	if !ok {
+		return nil
+	}
+
+	key := coinID + ":" + at.UTC().Format("2006-01-02")
+	if price, ok := prices[key]; ok {
+		return price
+	}
+
+	var price *float64
+	if p, err := j.coinGeckoClient.GetHistoricalPrice(ctx, coinID, at); err != nil {
+		logger.Warn("Failed to get historical price", "coin", coinID, "day", at.UTC().Format("2006-01-02"), "error", err)
+	} else {
+		price = &p
+	}
+	prices[key] = price
+	return price
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/feeds"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// feedRetention is how long feed items are kept. Older items are pruned, and skipped
+	// when a feed still lists them.
+	feedRetention = 180 * 24 * time.Hour
+	// feedMaxItemsPerFetch caps the items taken from one fetch of a source
+	feedMaxItemsPerFetch = 50
+	// feedFetchTimeout bounds the fetch of one source
+	feedFetchTimeout = 30 * time.Second
+)
+
+// feedFetcher reads a feed source; *feeds.Fetcher in production
+type feedFetcher interface {
+	Fetch(ctx context.Context, kind, url string, now time.Time) ([]feeds.Item, error)
+}
+
+// FeedIngestJob reads every enabled feed source into the news feed. Posts already
+// ingested, from the same source or syndicated by another, are skipped.
+type FeedIngestJob struct {
+	feedRepo repos.FeedRepository
+	fetcher  feedFetcher
+	clock    clock.Clock
+}
+
+func NewFeedIngestJob(feedRepo repos.FeedRepository, fetcher *feeds.Fetcher) *FeedIngestJob {
+	return &FeedIngestJob{
+		feedRepo: feedRepo,
+		fetcher:  fetcher,
+		clock:    clock.System,
+	}
+}
+
+func (j *FeedIngestJob) Run(ctx context.Context) error {
+	sources, err := j.feedRepo.GetEnabledSources(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := j.clock.Now()
+	cutoff := now.Add(-feedRetention)
+	added, failed := 0, 0
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, feedFetchTimeout)
+		items, fetchErr := j.fetcher.Fetch(fetchCtx, source.Kind, source.URL, now)
+		cancel()
+		if fetchErr != nil {
+			logger.Warn("Failed to fetch feed source", "sourceId", source.ID, "url", source.URL, "error", fetchErr)
+			failed++
+		} else {
+			n, err := j.feedRepo.AddItems(ctx, source.ID, recentFeedItems(items, cutoff))
+			if err != nil {
+				logger.Error("Failed to store feed items", "sourceId", source.ID, "error", err)
+			}
+			added += n
+		}
+		if err := j.feedRepo.MarkFetched(ctx, source.ID, fetchErr); err != nil {
+			logger.Error("Failed to mark feed source fetched", "sourceId", source.ID, "error", err)
+		}
+	}
+
+	pruned, err := j.feedRepo.DeleteItemsOlderThan(ctx, cutoff)
+	if err != nil {
+		logger.Error("Failed to prune feed items", "error", err)
+	}
+
+	logger.Info("Feed ingestion finished", "sources", len(sources), "failed", failed, "added", added, "pruned", pruned)
+	return nil
+}
+
+// recentFeedItems keeps at most feedMaxItemsPerFetch items published after cutoff
+func recentFeedItems(items []feeds.Item, cutoff time.Time) []feeds.Item {
+	recent := make([]feeds.Item, 0, len(items))
+	for _, item := range items {
+		if len(recent) == feedMaxItemsPerFetch {
+			break
+		}
+		if item.PublishedAt.After(cutoff) {
+			recent = append(recent, item)
+		}
+	}
+	return recent
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/feeds"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryFeedRepo struct {
+	repos.FeedRepository
+	sources []*models.FeedSource
+	items   map[uuid.UUID][]feeds.Item
+	errors  map[uuid.UUID]error
+	pruned  time.Time
+}
+
+func (r *memoryFeedRepo) GetEnabledSources(ctx context.Context) ([]*models.FeedSource, error) {
+	return r.sources, nil
+}
+
+func (r *memoryFeedRepo) AddItems(ctx context.Context, sourceID uuid.UUID, items []feeds.Item) (int, error) {
+	r.items[sourceID] = append(r.items[sourceID], items...)
+	return len(items), nil
+}
+
+func (r *memoryFeedRepo) MarkFetched(ctx context.Context, id uuid.UUID, fetchErr error) error {
+	r.errors[id] = fetchErr
+	return nil
+}
+
+func (r *memoryFeedRepo) DeleteItemsOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	r.pruned = before
+	return 0, nil
+}
+
+type fakeFeedFetcher map[string][]feeds.Item
+
+func (f fakeFeedFetcher) Fetch(ctx context.Context, kind, url string, now time.Time) ([]feeds.Item, error) {
+	items, ok := f[url]
+	if !ok {
+		return nil, fmt.Errorf("feed returned status 404")
+	}
+	return items, nil
+}
+
+func TestFeedIngestJob(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	forum := &models.FeedSource{ID: uuid.New(), Kind: feeds.KindDiscourse, URL: "https://gov.example.org"}
+	gone := &models.FeedSource{ID: uuid.New(), Kind: feeds.KindRSS, URL: "https://blog.example.org/rss"}
+	repo := &memoryFeedRepo{
+		sources: []*models.FeedSource{forum, gone},
+		items:   make(map[uuid.UUID][]feeds.Item),
+		errors:  make(map[uuid.UUID]error),
+	}
+
+	var topics []feeds.Item
+	for i := 0; i < feedMaxItemsPerFetch+5; i++ {
+		topics = append(topics, feeds.Item{GUID: fmt.Sprintf("topic-%d", i), PublishedAt: now.Add(-time.Duration(i) * time.Hour)})
+	}
+	// A pinned welcome topic from years ago
+	topics = append([]feeds.Item{{GUID: "topic-welcome", PublishedAt: now.AddDate(-3, 0, 0)}}, topics...)
+
+	job := NewFeedIngestJob(repo, nil)
+	job.fetcher = fakeFeedFetcher{forum.URL: topics}
+	job.clock = clock.NewFake(now)
+	require.NoError(t, job.Run(context.Background()))
+
+	stored := repo.items[forum.ID]
+	require.Len(t, stored, feedMaxItemsPerFetch)
+	assert.Equal(t, "topic-0", stored[0].GUID, "items past the retention are skipped")
+	assert.NoError(t, repo.errors[forum.ID])
+
+	assert.Empty(t, repo.items[gone.ID])
+	assert.EqualError(t, repo.errors[gone.ID], "feed returned status 404", "failed fetches are recorded on the source")
+	assert.Equal(t, now.Add(-feedRetention), repo.pruned)
+}
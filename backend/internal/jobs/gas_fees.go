@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// gasFeeBackfillBatchSize bounds how many transactions are enriched per round trip
+	gasFeeBackfillBatchSize = 200
+
+	gasFeeBackfillJobName = "gas-fee-backfill"
+
+	// gasFeeMaxAttempts is how many times a transaction's gas fee is tried before the
+	// backfill gives up on it; retries wait an hour, doubling each time
+	gasFeeMaxAttempts = 8
+)
+
+type GasFeeBackfillJob struct {
+	db                *pgxpool.Pool
+	blockchainService *blockchain.BlockchainService
+	checkpoints       *CheckpointStore
+}
+
+func NewGasFeeBackfillJob(db *pgxpool.Pool, blockchainService *blockchain.BlockchainService) *GasFeeBackfillJob {
+	return &GasFeeBackfillJob{
+		db:                db,
+		blockchainService: blockchainService,
+		checkpoints:       NewCheckpointStore(db),
+	}
+}
+
+// Run backfills gas_fee_usd for stored transactions in batches, resuming after
+// the last checkpointed transaction id if a previous run was interrupted. Ingestion
+// prices most transactions as it stores them; this catches those it couldn't, and
+// those stored before it did.
+func (j *GasFeeBackfillJob) Run(ctx context.Context) error {
+	var cursor uuid.UUID
+	saved, err := j.checkpoints.Load(ctx, gasFeeBackfillJobName)
+	if err != nil {
+		return err
+	}
+	if saved != "" {
+		if id, err := uuid.Parse(saved); err == nil {
+			cursor = id
+		}
+	}
+
+	logger.Info("Starting gas fee backfill job", "resumeAfter", saved)
+	enriched, scanned := 0, 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := j.getPendingTransactions(ctx, cursor)
+		if err != nil {
+			return fmt.Errorf("failed to get transactions without gas fee: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		scanned += len(batch)
+		cursor = batch[len(batch)-1].ID
+
+		j.blockchainService.EnrichGasFees(ctx, batch)
+
+		for _, tx := range batch {
+			// A fee that couldn't be priced still counts as an attempt, keeping any receipt
+			// figures found, and waits before the next one
+			_, err := j.db.Exec(ctx, `
+				UPDATE transactions
+				SET gas_used = COALESCE($1, gas_used),
+					gas_price = COALESCE($2::numeric, gas_price),
+					gas_fee_usd = $3,
+					gas_fee_attempts = gas_fee_attempts + 1,
+					gas_fee_retry_at = CASE WHEN $6 THEN NULL
+						ELSE NOW() + INTERVAL '1 hour' * POWER(2, gas_fee_attempts) END,
+					updated_at = NOW()
+				WHERE id = $4 AND chain_id = $5`,
+				tx.GasUsed, tx.GasPrice, tx.GasFeeUSD, tx.ID, tx.ChainID, tx.GasFeeUSD != nil)
+			if err != nil {
+				logger.Error("Failed to update transaction gas fee", "hash", tx.Hash, "error", err)
+				continue
+			}
+			if tx.GasFeeUSD != nil {
+				enriched++
+			}
+		}
+
+		// Only checkpoint batches that ran to completion
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := j.checkpoints.Save(ctx, gasFeeBackfillJobName, cursor.String()); err != nil {
+			logger.Warn("Failed to checkpoint gas fee backfill", "error", err)
+		}
+
+		if len(batch) < gasFeeBackfillBatchSize {
+			break
+		}
+	}
+
+	if err := j.checkpoints.Clear(ctx, gasFeeBackfillJobName); err != nil {
+		logger.Warn("Failed to clear gas fee backfill checkpoint", "error", err)
+	}
+
+	logger.Info("Gas fee backfill job completed", "scanned", scanned, "enriched", enriched)
+	return nil
+}
+
+// getPendingTransactions returns the next batch of transactions missing a USD gas fee that
+// are due another attempt, keyed by id
+func (j *GasFeeBackfillJob) getPendingTransactions(ctx context.Context, after uuid.UUID) ([]*models.Transaction, error) {
+	rows, err := j.db.Query(ctx, `
+		SELECT id, hash, chain_id, gas_used, gas_price::text, timestamp
+		FROM transactions
+		WHERE gas_fee_usd IS NULL AND status <> 'pending' AND id > $1
+		  AND gas_fee_attempts < $3 AND (gas_fee_retry_at IS NULL OR gas_fee_retry_at <= NOW())
+		ORDER BY id
+		LIMIT $2`,
+		after, gasFeeBackfillBatchSize, gasFeeMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		var tx models.Transaction
+		if err := rows.Scan(&tx.ID, &tx.Hash, &tx.ChainID, &tx.GasUsed, &tx.GasPrice, &tx.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, &tx)
+	}
+
+	return transactions, rows.Err()
+}
@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// lpPosition is what an impermanent loss alert values a yield position from
+type lpPosition struct {
+	UserID          uuid.UUID
+	IsActive        bool
+	EntryValueUSD   *float64
+	EntryPrices     map[string]float64
+	CurrentPrices   map[string]float64
+	CurrentValueUSD *float64
+	RewardsUSD      float64
+}
+
+// evaluateILAlerts values each alert's LP position against holding its entry tokens
+func (j *AlertEvaluatorJob) evaluateILAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	var positionIDs []uuid.UUID
+	for _, alert := range alerts {
+		if id, err := uuid.Parse(alert.Target.Identifier); err == nil {
+			positionIDs = append(positionIDs, id)
+		}
+	}
+	positions, err := j.getLPPositions(ctx, positionIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get LP positions: %w", err)
+	}
+
+	triggered := 0
+	for _, alert := range alerts {
+		id, err := uuid.Parse(alert.Target.Identifier)
+		if err != nil {
+			continue
+		}
+		position, ok := positions[id]
+		if !ok || !position.IsActive || position.UserID != alert.UserID || position.EntryValueUSD == nil {
+			continue
+		}
+
+		valuation, err := services.ValueLPPosition(*position.EntryValueUSD, position.EntryPrices, position.CurrentPrices, position.CurrentValueUSD, position.RewardsUSD)
+		if err != nil {
+			logger.Warn("Failed to value LP position", "alertId", alert.ID, "positionId", id, "error", err)
+			continue
+		}
+
+		triggeredValue := ilTrigger(alert.Conditions, valuation)
+		if triggeredValue == nil {
+			continue
+		}
+		triggeredValue["positionId"] = alert.Target.Identifier
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// ilTrigger returns the loss figures an impermanent loss alert fired on, or nil while the
+// loss is within its threshold
+func ilTrigger(conditions models.AlertConditions, valuation *services.LPValuation) map[string]interface{} {
+	if conditions.ILPercent == nil || valuation.ImpermanentLossPercent < *conditions.ILPercent {
+		return nil
+	}
+
+	triggeredValue := map[string]interface{}{
+		"ilPercent":     valuation.ImpermanentLossPercent,
+		"ilUsd":         valuation.ImpermanentLossUSD,
+		"threshold":     *conditions.ILPercent,
+		"holdValueUsd":  valuation.HoldValueUSD,
+		"poolValueUsd":  valuation.PoolValueUSD,
+		"entryValueUsd": valuation.EntryValueUSD,
+	}
+	if valuation.FeesUSD != nil {
+		triggeredValue["feesUsd"] = *valuation.FeesUSD
+		triggeredValue["netVsHoldUsd"] = *valuation.NetVsHoldUSD
+	}
+	if valuation.FeeOffsetPercent != nil {
+		triggeredValue["feeOffsetPercent"] = *valuation.FeeOffsetPercent
+	}
+	return triggeredValue
+}
+
+// getLPPositions returns each position found with its entry prices and the current
+// prices of the same tokens, keyed by position ID
+func (j *AlertEvaluatorJob) getLPPositions(ctx context.Context, positionIDs []uuid.UUID) (map[uuid.UUID]lpPosition, error) {
+	positions := make(map[uuid.UUID]lpPosition, len(positionIDs))
+	if len(positionIDs) == 0 {
+		return positions, nil
+	}
+
+	rows, err := j.db.Query(ctx, `
+		SELECT yp.id, yp.user_id, COALESCE(yp.is_active, TRUE), yp.entry_price_usd::float8,
+		       yp.entry_token_prices,
+		       (SELECT jsonb_object_agg(LOWER(t.address), p.price_usd::float8)
+		        FROM tokens t
+		        JOIN token_prices_latest p ON p.token_id = t.id
+		        WHERE t.chain_id = yp.chain_id
+		          AND LOWER(t.address) IN (SELECT jsonb_object_keys(yp.entry_token_prices))),
+		       yp.current_value_usd::float8, COALESCE(yp.total_rewards_usd, 0)::float8
+		FROM yield_positions yp
+		WHERE yp.id = ANY($1) AND yp.entry_token_prices IS NOT NULL`,
+		positionIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var position lpPosition
+		var entryJSON, currentJSON []byte
+		if err := rows.Scan(&id, &position.UserID, &position.IsActive, &position.EntryValueUSD,
+			&entryJSON, &currentJSON, &position.CurrentValueUSD, &position.RewardsUSD); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(entryJSON, &position.EntryPrices); err != nil {
+			return nil, fmt.Errorf("failed to parse entry token prices: %w", err)
+		}
+		if currentJSON != nil {
+			if err := json.Unmarshal(currentJSON, &position.CurrentPrices); err != nil {
+				return nil, fmt.Errorf("failed to parse current token prices: %w", err)
+			}
+		}
+		positions[id] = position
+	}
+
+	return positions, rows.Err()
+}
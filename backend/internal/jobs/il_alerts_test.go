@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestILTrigger(t *testing.T) {
+	fees, net, offset := 450.0, -400.0, 52.5
+	valuation := &services.LPValuation{
+		EntryValueUSD:          10000,
+		HoldValueUSD:           15000,
+		PoolValueUSD:           14142.14,
+		ImpermanentLossUSD:     857.86,
+		ImpermanentLossPercent: 5.72,
+		FeesUSD:                &fees,
+		FeeOffsetPercent:       &offset,
+		NetVsHoldUSD:           &net,
+	}
+
+	got := ilTrigger(models.AlertConditions{ILPercent: floatPtr(5)}, valuation)
+	require.NotNil(t, got)
+	assert.Equal(t, 5.72, got["ilPercent"])
+	assert.Equal(t, 857.86, got["ilUsd"])
+	assert.Equal(t, 450.0, got["feesUsd"])
+	assert.Equal(t, 52.5, got["feeOffsetPercent"])
+	assert.Equal(t, -400.0, got["netVsHoldUsd"])
+
+	assert.Nil(t, ilTrigger(models.AlertConditions{ILPercent: floatPtr(6)}, valuation))
+	assert.Nil(t, ilTrigger(models.AlertConditions{}, valuation))
+}
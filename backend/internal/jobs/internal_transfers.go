@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+)
+
+// internalTransferLookback is how far back runs after the first look for transfers to
+// pair; it covers lots synced late
+const internalTransferLookback = 7 * 24 * time.Hour
+
+// InternalTransferMatchJob pairs transfers between users' own wallets, on one chain or
+// bridged across chains, so they are excluded from realized PnL and keep their cost basis
+type InternalTransferMatchJob struct {
+	pnlService pnl.Service
+	backfilled bool // the first run in a process scans all history
+	clock      clock.Clock
+}
+
+func NewInternalTransferMatchJob(pnlService pnl.Service) *InternalTransferMatchJob {
+	return &InternalTransferMatchJob{pnlService: pnlService, clock: clock.System}
+}
+
+func (j *InternalTransferMatchJob) Run(ctx context.Context) error {
+	var since time.Time
+	if j.backfilled {
+		since = j.clock.Now().Add(-internalTransferLookback)
+	}
+
+	paired, err := j.pnlService.MatchInternalTransfers(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to match internal transfers: %w", err)
+	}
+	j.backfilled = true
+
+	if paired > 0 {
+		logger.Info("Internal transfer matching completed", "paired", paired)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// Health factors below these escalate past the alert's own watch threshold
+	warningHealthFactor  = 1.25
+	criticalHealthFactor = 1.1
+	// riskHysteresis is how far above a level's boundary the health factor must
+	// recover before the level steps down, so values hovering at a boundary don't flap
+	riskHysteresis = 0.05
+	// idleCheckInterval is how often positions that aren't at risk are re-read;
+	// positions at watch level or worse are checked on every run
+	idleCheckInterval = 10 * time.Minute
+)
+
+var riskLevelRank = map[string]int{
+	models.LiquidationRiskOK:       0,
+	models.LiquidationRiskWatch:    1,
+	models.LiquidationRiskWarning:  2,
+	models.LiquidationRiskCritical: 3,
+}
+
+var riskLevelsByRank = []string{
+	models.LiquidationRiskOK,
+	models.LiquidationRiskWatch,
+	models.LiquidationRiskWarning,
+	models.LiquidationRiskCritical,
+}
+
+// riskReminderInterval is how often an unchanged level is notified again; watch
+// level only notifies on entry
+var riskReminderInterval = map[string]time.Duration{
+	models.LiquidationRiskWarning:  6 * time.Hour,
+	models.LiquidationRiskCritical: 30 * time.Minute,
+}
+
+// debtPositionReader reads a wallet's lending positions; *blockchain.BlockchainService
+// in production
+type debtPositionReader interface {
+	GetDebtPositions(ctx context.Context, address string, chainID int) ([]*models.DebtPosition, error)
+}
+
+// LiquidationMonitorJob watches health factor alerts every minute and escalates
+// notifications as positions approach liquidation: push at watch level, plus email
+// at warning, plus webhook (bypassing quiet hours) at critical
+type LiquidationMonitorJob struct {
+	alertRepo repos.AlertRepository
+	riskRepo  repos.LiquidationRiskRepository
+	positions debtPositionReader
+	outbox    services.NotificationOutbox
+	publisher events.Publisher
+	clock     clock.Clock
+}
+
+func NewLiquidationMonitorJob(alertRepo repos.AlertRepository, riskRepo repos.LiquidationRiskRepository, blockchainService *blockchain.BlockchainService, outbox services.NotificationOutbox, publisher events.Publisher) *LiquidationMonitorJob {
+	return &LiquidationMonitorJob{
+		alertRepo: alertRepo,
+		riskRepo:  riskRepo,
+		positions: blockchainService,
+		outbox:    outbox,
+		publisher: publisher,
+		clock:     clock.System,
+	}
+}
+
+// Run re-reads due positions and notifies on escalations and reminders
+func (j *LiquidationMonitorJob) Run(ctx context.Context) error {
+	active, err := j.alertRepo.GetActiveAlerts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var alerts []models.Alert
+	var alertIDs []uuid.UUID
+	for _, alert := range active {
+		if alert.Type != models.AlertTypeHealthFactor || alert.Target.Type != "address" || alert.Conditions.HealthFactor == nil {
+			continue
+		}
+		if !blockchain.SupportsDebtPositions(alert.Target.ChainID) {
+			continue
+		}
+		alerts = append(alerts, alert)
+		alertIDs = append(alertIDs, alert.ID)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	states, err := j.riskRepo.GetStates(ctx, alertIDs)
+	if err != nil {
+		return err
+	}
+
+	now := j.clock.Now()
+	riskiest := make(map[tokenKey]*models.DebtPosition) // wallet -> riskiest position, so each wallet is read once
+	checked, notified := 0, 0
+	for i := range alerts {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		alert := &alerts[i]
+
+		state := states[alert.ID]
+		if state == nil {
+			state = &models.LiquidationRiskState{
+				AlertID:       alert.ID,
+				Level:         models.LiquidationRiskOK,
+				NotifiedLevel: models.LiquidationRiskOK,
+			}
+		} else if state.Level == models.LiquidationRiskOK && now.Sub(state.CheckedAt) < idleCheckInterval {
+			continue
+		}
+
+		key := newTokenKey(alert.Target.Identifier, alert.Target.ChainID)
+		position, cached := riskiest[key]
+		if !cached {
+			positions, err := j.positions.GetDebtPositions(ctx, alert.Target.Identifier, alert.Target.ChainID)
+			if err != nil {
+				logger.Error("Failed to get debt positions",
+					"address", alert.Target.Identifier,
+					"chainId", alert.Target.ChainID,
+					"error", err)
+				continue
+			}
+			position = lowestHealthFactor(positions)
+			riskiest[key] = position
+		}
+		checked++
+
+		var healthFactor *float64
+		level := models.LiquidationRiskOK
+		if position != nil {
+			hf := position.HealthFactor
+			healthFactor = &hf
+			level = nextRiskLevel(state.Level, hf, *alert.Conditions.HealthFactor)
+		}
+
+		state.Level = level
+		state.HealthFactor = healthFactor
+		state.CheckedAt = now
+
+		if shouldNotifyRisk(state, now) {
+			if err := j.notify(ctx, alert, position, level); err != nil {
+				logger.Error("Failed to send liquidation risk notification", "alertId", alert.ID, "level", level, "error", err)
+			} else {
+				state.NotifiedLevel = level
+				state.NotifiedAt = &now
+				notified++
+			}
+		} else if riskLevelRank[level] < riskLevelRank[state.NotifiedLevel] {
+			// Recovered: forget the notified level so a relapse notifies again
+			state.NotifiedLevel = level
+		}
+
+		if err := j.riskRepo.Upsert(ctx, state); err != nil {
+			logger.Error("Failed to store liquidation risk state", "alertId", alert.ID, "error", err)
+		}
+	}
+
+	if notified > 0 {
+		logger.Info("Liquidation monitor completed", "alerts", len(alerts), "checked", checked, "notified", notified)
+	}
+	return nil
+}
+
+// notify pushes a realtime event and delivers the alert on the channels for its level
+func (j *LiquidationMonitorJob) notify(ctx context.Context, alert *models.Alert, position *models.DebtPosition, level string) error {
+	triggeredValue := map[string]interface{}{
+		"level":                  level,
+		"healthFactor":           position.HealthFactor,
+		"threshold":              *alert.Conditions.HealthFactor,
+		"protocol":               position.Protocol,
+		"market":                 position.Market,
+		"debtUsd":                position.DebtUSD,
+		"collateralUsd":          position.CollateralUSD,
+		"liquidationDropPercent": position.LiquidationDropPercent,
+		"address":                alert.Target.Identifier,
+	}
+
+	event, err := events.NewEvent(events.TypeLiquidationRisk, alert.UserID, triggeredValue)
+	if err == nil {
+		err = j.publisher.Publish(ctx, event)
+	}
+	if err != nil {
+		// The push is best effort; email and webhook still go out
+		logger.Warn("Failed to publish liquidation risk event", "alertId", alert.ID, "error", err)
+	}
+
+	history := &models.AlertHistory{
+		ID:                 uuid.New(),
+		AlertID:            alert.ID,
+		TriggeredAt:        j.clock.Now(),
+		ConditionsSnapshot: alert.Conditions,
+		TriggeredValue:     triggeredValue,
+	}
+	outboxID, err := j.alertRepo.RecordTrigger(ctx, history, riskChannels(level, alert.Notification), level == models.LiquidationRiskCritical)
+	if err != nil {
+		return err
+	}
+
+	// A failed send stays in the outbox for the outbox job to retry
+	if err := j.outbox.Dispatch(ctx, outboxID); err != nil {
+		logger.Warn("Failed to dispatch liquidation risk notification", "alertId", alert.ID, "error", err)
+	}
+	return nil
+}
+
+// riskChannels narrows the alert's configured channels to those its level warrants
+func riskChannels(level string, configured models.AlertNotification) models.AlertNotification {
+	if level == models.LiquidationRiskCritical {
+		return configured
+	}
+	var channels models.AlertNotification
+	if riskLevelRank[level] >= riskLevelRank[models.LiquidationRiskWarning] {
+		channels.Email = configured.Email
+		channels.InApp = configured.InApp
+	}
+	return channels
+}
+
+// riskLevelFor maps a health factor to its level with no hysteresis
+func riskLevelFor(healthFactor, watchThreshold float64) string {
+	switch {
+	case healthFactor < criticalHealthFactor:
+		return models.LiquidationRiskCritical
+	case healthFactor < warningHealthFactor:
+		return models.LiquidationRiskWarning
+	case healthFactor < watchThreshold:
+		return models.LiquidationRiskWatch
+	default:
+		return models.LiquidationRiskOK
+	}
+}
+
+// riskLevelBoundary is the health factor below which a level applies
+func riskLevelBoundary(level string, watchThreshold float64) float64 {
+	switch level {
+	case models.LiquidationRiskCritical:
+		return criticalHealthFactor
+	case models.LiquidationRiskWarning:
+		return warningHealthFactor
+	default:
+		return watchThreshold
+	}
+}
+
+// nextRiskLevel escalates immediately but steps down only once the health factor
+// clears the current level's boundary by riskHysteresis
+func nextRiskLevel(previous string, healthFactor, watchThreshold float64) string {
+	level := riskLevelFor(healthFactor, watchThreshold)
+	for rank := riskLevelRank[previous]; rank > riskLevelRank[level]; rank-- {
+		candidate := riskLevelsByRank[rank]
+		if healthFactor < riskLevelBoundary(candidate, watchThreshold)+riskHysteresis {
+			return candidate
+		}
+	}
+	return level
+}
+
+// shouldNotifyRisk notifies when the level rises above the last notified level, and
+// repeats warning and critical notifications at their reminder interval
+func shouldNotifyRisk(state *models.LiquidationRiskState, now time.Time) bool {
+	if state.Level == models.LiquidationRiskOK {
+		return false
+	}
+	if riskLevelRank[state.Level] > riskLevelRank[state.NotifiedLevel] {
+		return true
+	}
+	if state.Level != state.NotifiedLevel {
+		return false
+	}
+	interval, ok := riskReminderInterval[state.Level]
+	return ok && state.NotifiedAt != nil && now.Sub(*state.NotifiedAt) >= interval
+}
+
+// lowestHealthFactor returns the position closest to liquidation, or nil if there is none
+func lowestHealthFactor(positions []*models.DebtPosition) *models.DebtPosition {
+	var lowest *models.DebtPosition
+	for _, position := range positions {
+		if lowest == nil || position.HealthFactor < lowest.HealthFactor {
+			lowest = position
+		}
+	}
+	return lowest
+}
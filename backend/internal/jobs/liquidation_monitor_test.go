@@ -0,0 +1,220 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLowestHealthFactor(t *testing.T) {
+	assert.Nil(t, lowestHealthFactor(nil))
+
+	positions := []*models.DebtPosition{
+		{Protocol: models.DebtProtocolAaveV3, HealthFactor: 1.8},
+		{Protocol: models.DebtProtocolCompoundV3, HealthFactor: 1.15},
+	}
+	assert.Equal(t, models.DebtProtocolCompoundV3, lowestHealthFactor(positions).Protocol)
+}
+
+func TestNextRiskLevel(t *testing.T) {
+	const watch = 1.5
+
+	// Escalation is immediate
+	assert.Equal(t, models.LiquidationRiskWatch, nextRiskLevel(models.LiquidationRiskOK, 1.4, watch))
+	assert.Equal(t, models.LiquidationRiskCritical, nextRiskLevel(models.LiquidationRiskOK, 1.05, watch))
+
+	// Stepping down needs the health factor to clear the boundary by the hysteresis margin
+	assert.Equal(t, models.LiquidationRiskCritical, nextRiskLevel(models.LiquidationRiskCritical, 1.12, watch))
+	assert.Equal(t, models.LiquidationRiskWarning, nextRiskLevel(models.LiquidationRiskCritical, 1.2, watch))
+	assert.Equal(t, models.LiquidationRiskWatch, nextRiskLevel(models.LiquidationRiskWarning, 1.31, watch))
+	assert.Equal(t, models.LiquidationRiskWatch, nextRiskLevel(models.LiquidationRiskWatch, 1.52, watch))
+	assert.Equal(t, models.LiquidationRiskOK, nextRiskLevel(models.LiquidationRiskCritical, 2.0, watch))
+}
+
+func TestShouldNotifyRisk(t *testing.T) {
+	now := time.Now()
+	recently := now.Add(-time.Minute)
+	longAgo := now.Add(-time.Hour)
+
+	state := func(level, notified string, notifiedAt *time.Time) *models.LiquidationRiskState {
+		return &models.LiquidationRiskState{Level: level, NotifiedLevel: notified, NotifiedAt: notifiedAt}
+	}
+
+	assert.False(t, shouldNotifyRisk(state(models.LiquidationRiskOK, models.LiquidationRiskOK, nil), now))
+	assert.True(t, shouldNotifyRisk(state(models.LiquidationRiskWatch, models.LiquidationRiskOK, nil), now))
+	assert.True(t, shouldNotifyRisk(state(models.LiquidationRiskCritical, models.LiquidationRiskWarning, &recently), now))
+
+	// Same level only repeats at the reminder interval, and watch never repeats
+	assert.False(t, shouldNotifyRisk(state(models.LiquidationRiskCritical, models.LiquidationRiskCritical, &recently), now))
+	assert.True(t, shouldNotifyRisk(state(models.LiquidationRiskCritical, models.LiquidationRiskCritical, &longAgo), now))
+	assert.False(t, shouldNotifyRisk(state(models.LiquidationRiskWarning, models.LiquidationRiskWarning, &longAgo), now))
+	assert.False(t, shouldNotifyRisk(state(models.LiquidationRiskWatch, models.LiquidationRiskWatch, &longAgo), now))
+
+	// De-escalation is silent
+	assert.False(t, shouldNotifyRisk(state(models.LiquidationRiskWatch, models.LiquidationRiskCritical, &longAgo), now))
+}
+
+func TestRiskChannels(t *testing.T) {
+	configured := models.AlertNotification{Email: true, Webhook: "https://example.com/hook"}
+
+	assert.Equal(t, models.AlertNotification{}, riskChannels(models.LiquidationRiskWatch, configured))
+	assert.Equal(t, models.AlertNotification{Email: true}, riskChannels(models.LiquidationRiskWarning, configured))
+	assert.Equal(t, configured, riskChannels(models.LiquidationRiskCritical, configured))
+
+	// Chat channels are kept for critical risk, in-app goes out from warning up
+	all := models.AlertNotification{Email: true, InApp: true, Telegram: "-100123", Discord: "https://discord.com/api/webhooks/1/x"}
+	assert.Equal(t, models.AlertNotification{Email: true, InApp: true}, riskChannels(models.LiquidationRiskWarning, all))
+	assert.Equal(t, all, riskChannels(models.LiquidationRiskCritical, all))
+}
+
+// riskAlertRepo serves the active alerts and records the triggers
+type riskAlertRepo struct {
+	repos.AlertRepository
+	alerts   []models.Alert
+	triggers []riskTrigger
+}
+
+type riskTrigger struct {
+	alertID      uuid.UUID
+	notification models.AlertNotification
+	urgent       bool
+}
+
+func (r *riskAlertRepo) GetActiveAlerts(ctx context.Context) ([]models.Alert, error) {
+	return r.alerts, nil
+}
+
+func (r *riskAlertRepo) RecordTrigger(ctx context.Context, history *models.AlertHistory, notification models.AlertNotification, urgent bool) (uuid.UUID, error) {
+	r.triggers = append(r.triggers, riskTrigger{history.AlertID, notification, urgent})
+	return history.ID, nil
+}
+
+type memoryRiskRepo struct {
+	states map[uuid.UUID]*models.LiquidationRiskState
+}
+
+func (r *memoryRiskRepo) GetStates(ctx context.Context, alertIDs []uuid.UUID) (map[uuid.UUID]*models.LiquidationRiskState, error) {
+	states := make(map[uuid.UUID]*models.LiquidationRiskState)
+	for _, id := range alertIDs {
+		if state, ok := r.states[id]; ok {
+			copied := *state
+			states[id] = &copied
+		}
+	}
+	return states, nil
+}
+
+func (r *memoryRiskRepo) Upsert(ctx context.Context, state *models.LiquidationRiskState) error {
+	copied := *state
+	r.states[state.AlertID] = &copied
+	return nil
+}
+
+// fakeDebtPositions serves positions by wallet and counts the reads
+type fakeDebtPositions struct {
+	positions map[string][]*models.DebtPosition
+	failing   map[string]bool
+	reads     int
+}
+
+func (f *fakeDebtPositions) GetDebtPositions(ctx context.Context, address string, chainID int) ([]*models.DebtPosition, error) {
+	f.reads++
+	if f.failing[address] {
+		return nil, errors.New("rpc unavailable")
+	}
+	return f.positions[address], nil
+}
+
+func healthFactorAlert(address string, threshold float64, notification models.AlertNotification) models.Alert {
+	return models.Alert{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		Type:         models.AlertTypeHealthFactor,
+		Target:       models.AlertTarget{Type: "address", Identifier: address, ChainID: 1},
+		Conditions:   models.AlertConditions{HealthFactor: &threshold},
+		Notification: notification,
+	}
+}
+
+func TestLiquidationMonitorJobEscalates(t *testing.T) {
+	const wallet = "0x1111111111111111111111111111111111111111"
+	channels := models.AlertNotification{Email: true, Webhook: "https://example.com/hook"}
+	first := healthFactorAlert(wallet, 1.5, channels)
+	second := healthFactorAlert(wallet, 2, channels)
+	price := models.Alert{ID: uuid.New(), Type: models.AlertTypePriceAbove, Target: models.AlertTarget{Type: "token", ChainID: 1}}
+
+	alertRepo := &riskAlertRepo{alerts: []models.Alert{first, second, price}}
+	riskRepo := &memoryRiskRepo{states: map[uuid.UUID]*models.LiquidationRiskState{}}
+	reader := &fakeDebtPositions{positions: map[string][]*models.DebtPosition{wallet: {
+		{Protocol: models.DebtProtocolAaveV3, HealthFactor: 1.8},
+		{Protocol: models.DebtProtocolCompoundV3, HealthFactor: 1.2},
+	}}}
+	outbox := &recordingOutbox{}
+	publisher := &recordingPublisher{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	job := &LiquidationMonitorJob{alertRepo: alertRepo, riskRepo: riskRepo, positions: reader, outbox: outbox, publisher: publisher, clock: fake}
+
+	// The riskiest position is at warning level; the wallet is read once for both alerts
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, 1, reader.reads)
+	require.Len(t, alertRepo.triggers, 2)
+	assert.Equal(t, riskTrigger{first.ID, models.AlertNotification{Email: true}, false}, alertRepo.triggers[0])
+	assert.Len(t, outbox.dispatched, 2)
+	assert.Len(t, publisher.events, 2)
+	state := riskRepo.states[first.ID]
+	assert.Equal(t, models.LiquidationRiskWarning, state.Level)
+	assert.Equal(t, models.LiquidationRiskWarning, state.NotifiedLevel)
+	require.NotNil(t, state.HealthFactor)
+	assert.Equal(t, 1.2, *state.HealthFactor)
+
+	// An unchanged level isn't notified again until its reminder is due
+	fake.Advance(time.Minute)
+	require.NoError(t, job.Run(context.Background()))
+	assert.Len(t, alertRepo.triggers, 2)
+
+	// Critical goes out on every configured channel, bypassing quiet hours
+	reader.positions[wallet][1].HealthFactor = 1.05
+	fake.Advance(time.Minute)
+	require.NoError(t, job.Run(context.Background()))
+	require.Len(t, alertRepo.triggers, 4)
+	assert.Equal(t, riskTrigger{first.ID, channels, true}, alertRepo.triggers[2])
+	assert.Equal(t, models.LiquidationRiskCritical, riskRepo.states[first.ID].NotifiedLevel)
+}
+
+func TestLiquidationMonitorJobSkipsIdleAndFailedReads(t *testing.T) {
+	const (
+		idle   = "0x1111111111111111111111111111111111111111"
+		broken = "0x2222222222222222222222222222222222222222"
+	)
+	idleAlert := healthFactorAlert(idle, 1.5, models.AlertNotification{Email: true})
+	brokenAlert := healthFactorAlert(broken, 1.5, models.AlertNotification{Email: true})
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	riskRepo := &memoryRiskRepo{states: map[uuid.UUID]*models.LiquidationRiskState{
+		idleAlert.ID: {AlertID: idleAlert.ID, Level: models.LiquidationRiskOK, NotifiedLevel: models.LiquidationRiskOK, CheckedAt: now.Add(-time.Minute)},
+	}}
+	reader := &fakeDebtPositions{failing: map[string]bool{broken: true}}
+	alertRepo := &riskAlertRepo{alerts: []models.Alert{idleAlert, brokenAlert}}
+	job := &LiquidationMonitorJob{alertRepo: alertRepo, riskRepo: riskRepo, positions: reader, outbox: &recordingOutbox{}, publisher: &recordingPublisher{}, clock: clock.NewFake(now)}
+
+	// The idle alert was checked recently, and the failed read leaves no state behind
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, 1, reader.reads)
+	assert.Empty(t, alertRepo.triggers)
+	assert.NotContains(t, riskRepo.states, brokenAlert.ID)
+
+	// Once the idle interval has passed, the idle alert is read again
+	job.clock = clock.NewFake(now.Add(idleCheckInterval))
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, 3, reader.reads)
+	assert.Equal(t, now.Add(idleCheckInterval), riskRepo.states[idleAlert.ID].CheckedAt)
+}
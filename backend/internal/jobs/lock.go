@@ -0,0 +1,148 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// JobLocker ensures a job runs on only one worker replica at a time using
+// Postgres session-level advisory locks. A replica that crashes mid-run drops
+// its connection, which releases the lock automatically. The lock only keeps
+// runs from overlapping, so scheduled runs also record the tick they ran for
+// in job_ticks, and a replica whose cron fires after the tick was run skips it.
+type JobLocker struct {
+	db    *pgxpool.Pool
+	runs  JobRunRecorder
+	clock clock.Clock
+}
+
+// JobRunRecorder keeps each job's latest run for the status endpoint;
+// repos.SystemStatusRepository in production
+type JobRunRecorder interface {
+	RecordJobRun(ctx context.Context, jobName string, startedAt, finishedAt time.Time, runErr error, degraded string) error
+}
+
+// runNotes collects what a job reports about its run besides its error
+type runNotes struct {
+	mu       sync.Mutex
+	degraded []string
+}
+
+type runNotesKey struct{}
+
+// MarkDegraded notes that the job running with ctx left work undone, and why. The run
+// still succeeds, but is recorded as degraded for the status endpoint. It's a no-op
+// outside a run, such as when an alert is evaluated on demand.
+func MarkDegraded(ctx context.Context, reason string) {
+	notes, _ := ctx.Value(runNotesKey{}).(*runNotes)
+	if notes == nil {
+		return
+	}
+	notes.mu.Lock()
+	defer notes.mu.Unlock()
+	notes.degraded = append(notes.degraded, reason)
+}
+
+func (n *runNotes) degradedReason() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return strings.Join(n.degraded, "; ")
+}
+
+func NewJobLocker(db *pgxpool.Pool) *JobLocker {
+	return &JobLocker{db: db, clock: clock.System}
+}
+
+// RecordRuns records every run the locker lets through
+func (l *JobLocker) RecordRuns(runs JobRunRecorder) {
+	l.runs = runs
+}
+
+// RunExclusive runs fn if no other replica holds the lock for jobName.
+// It reports false without running fn when the lock is taken, or when a
+// replica already ran the tick the scheduler put on ctx.
+func (l *JobLocker) RunExclusive(ctx context.Context, jobName string, fn func(context.Context) error) (bool, error) {
+	// Advisory locks belong to the session, so hold one connection for the whole run
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection for job lock: %w", err)
+	}
+
+	key := jobLockKey(jobName)
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Release()
+		return false, fmt.Errorf("failed to take lock for %s: %w", jobName, err)
+	}
+	if !locked {
+		conn.Release()
+		return false, nil
+	}
+
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			// Never hand a connection still holding the lock back to the pool
+			logger.Error("Failed to release job lock, closing connection", "job", jobName, "error", err)
+			conn.Hijack().Close(unlockCtx)
+			return
+		}
+		conn.Release()
+	}()
+
+	tick, scheduled := scheduledTick(ctx)
+	if scheduled {
+		var done bool
+		if err := conn.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM job_ticks WHERE job_name = $1 AND tick >= $2)`,
+			jobName, tick).Scan(&done); err != nil {
+			return false, fmt.Errorf("failed to read last tick of %s: %w", jobName, err)
+		}
+		if done {
+			return false, nil
+		}
+	}
+
+	startedAt := l.clock.Now()
+	notes := &runNotes{}
+	err = fn(context.WithValue(ctx, runNotesKey{}, notes))
+
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	// A failed run counts too: the tick had its run, and the next tick retries
+	if scheduled {
+		if _, recordErr := conn.Exec(recordCtx, `
+			INSERT INTO job_ticks (job_name, tick, completed_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (job_name) DO UPDATE SET
+				tick = GREATEST(job_ticks.tick, EXCLUDED.tick),
+				completed_at = EXCLUDED.completed_at`,
+			jobName, tick); recordErr != nil {
+			logger.Warn("Failed to record job tick", "job", jobName, "tick", tick, "error", recordErr)
+		}
+	}
+	if l.runs != nil {
+		if recordErr := l.runs.RecordJobRun(recordCtx, jobName, startedAt, l.clock.Now(), err, notes.degradedReason()); recordErr != nil {
+			logger.Warn("Failed to record job run", "job", jobName, "error", recordErr)
+		}
+	}
+	return true, err
+}
+
+// jobLockKey maps a job name to the bigint key space of pg advisory locks
+func jobLockKey(jobName string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("defi-dashboard:job:" + jobName))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkDegraded(t *testing.T) {
+	notes := &runNotes{}
+	ctx := context.WithValue(context.Background(), runNotesKey{}, notes)
+	assert.Empty(t, notes.degradedReason())
+
+	MarkDegraded(ctx, "skipped 2 alerts on stale data")
+	MarkDegraded(ctx, "pool sync was late")
+	assert.Equal(t, "skipped 2 alerts on stale data; pool sync was late", notes.degradedReason())
+
+	// Outside a run there's nothing to note it on
+	MarkDegraded(context.Background(), "ignored")
+}
\ No newline at end of file
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// MonthlyStatementJob generates last month's PDF statements for opted-in users
+type MonthlyStatementJob struct {
+	reportService *services.ReportService
+}
+
+func NewMonthlyStatementJob(reportService *services.ReportService) *MonthlyStatementJob {
+	return &MonthlyStatementJob{reportService: reportService}
+}
+
+// Run generates every statement still due for last month. Users whose statement failed
+// or didn't fit in the run are picked up by the next one.
+func (j *MonthlyStatementJob) Run(ctx context.Context) error {
+	generated, err := j.reportService.GenerateMonthly(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate monthly statements: %w", err)
+	}
+
+	if generated > 0 {
+		logger.Info("Monthly statement generation completed", "generated", generated)
+	}
+	return nil
+}
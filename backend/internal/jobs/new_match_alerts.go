@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// newMatchLimit caps how many new pools one trigger lists
+const newMatchLimit = 10
+
+// evaluateNewMatchAlerts runs each alert's saved yield search over the pools first seen
+// since the alert last fired, or since it was created, and fires when any match. Pools
+// seen earlier that only start matching later, e.g. as their APY rises, don't count.
+func (j *AlertEvaluatorJob) evaluateNewMatchAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	triggered := 0
+	for _, alert := range alerts {
+		id, err := uuid.Parse(alert.Target.Identifier)
+		if err != nil {
+			continue
+		}
+		saved, err := j.savedSearchRepo.GetByID(ctx, id)
+		if err != nil {
+			logger.Warn("Failed to get saved search", "alertId", alert.ID, "savedSearchId", id, "error", err)
+			continue
+		}
+		if saved == nil || saved.UserID != alert.UserID || saved.Kind != models.SavedSearchKindYieldPools {
+			continue
+		}
+
+		search, err := services.YieldPoolSearchFromParams(saved.Params)
+		if err != nil {
+			logger.Warn("Saved search has invalid filters", "alertId", alert.ID, "savedSearchId", id, "error", err)
+			continue
+		}
+		since := alert.CreatedAt
+		if alert.LastTriggeredAt != nil {
+			since = *alert.LastTriggeredAt
+		}
+		search.CreatedAfter = &since
+		search.Limit = newMatchLimit
+
+		pools, total, err := j.yieldPoolRepo.Search(ctx, search)
+		if err != nil {
+			logger.Warn("Failed to run saved search", "alertId", alert.ID, "savedSearchId", id, "error", err)
+			continue
+		}
+		triggeredValue := newMatchTrigger(saved, pools, total, since)
+		if triggeredValue == nil {
+			continue
+		}
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// newMatchTrigger describes the pools a new match alert fired on, or returns nil when
+// there are none
+func newMatchTrigger(saved *models.SavedSearch, pools []*models.YieldPool, total int64, since time.Time) map[string]interface{} {
+	if total == 0 || len(pools) == 0 {
+		return nil
+	}
+
+	matches := make([]map[string]interface{}, 0, len(pools))
+	for _, pool := range pools {
+		match := map[string]interface{}{
+			"poolId":  pool.ID,
+			"name":    pool.PoolName,
+			"symbol":  pool.Symbol,
+			"chainId": pool.ChainID,
+			"apy":     pool.APY,
+			"tvlUsd":  pool.TVLUSD,
+		}
+		if pool.Protocol != nil {
+			match["protocol"] = pool.Protocol.Name
+		}
+		matches = append(matches, match)
+	}
+
+	return map[string]interface{}{
+		"savedSearchId": saved.ID,
+		"name":          saved.Name,
+		"total":         total,
+		"since":         since,
+		"pools":         matches,
+	}
+}
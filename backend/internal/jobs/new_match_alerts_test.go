@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMatchTrigger(t *testing.T) {
+	saved := &models.SavedSearch{ID: uuid.New(), Name: "Stable farms"}
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, newMatchTrigger(saved, nil, 0, since))
+
+	pools := []*models.YieldPool{
+		{ID: uuid.New(), PoolName: "USDC Lending", Symbol: "USDC", APY: floatPtr(6.1), Protocol: &models.Protocol{Name: "Aave V3"}},
+		{ID: uuid.New(), PoolName: "DAI Vault", Symbol: "DAI"},
+	}
+	got := newMatchTrigger(saved, pools, 12, since)
+	require.NotNil(t, got)
+	assert.Equal(t, saved.ID, got["savedSearchId"])
+	assert.Equal(t, "Stable farms", got["name"])
+	assert.Equal(t, int64(12), got["total"])
+	assert.Equal(t, since, got["since"])
+
+	matches := got["pools"].([]map[string]interface{})
+	require.Len(t, matches, 2)
+	assert.Equal(t, "USDC Lending", matches[0]["name"])
+	assert.Equal(t, "Aave V3", matches[0]["protocol"])
+	assert.NotContains(t, matches[1], "protocol")
+}
@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const nftTransferSyncJobName = "nft-transfer-sync"
+
+type NFTTransferSyncJob struct {
+	db                *pgxpool.Pool
+	blockchainService *blockchain.BlockchainService
+	pnlService        pnl.Service
+	checkpoints       *CheckpointStore
+}
+
+func NewNFTTransferSyncJob(db *pgxpool.Pool, blockchainService *blockchain.BlockchainService, pnlService pnl.Service) *NFTTransferSyncJob {
+	return &NFTTransferSyncJob{
+		db:                db,
+		blockchainService: blockchainService,
+		pnlService:        pnlService,
+		checkpoints:       NewCheckpointStore(db),
+	}
+}
+
+// Run fetches NFT transfers for every tracked wallet and stores them for NFT PnL.
+// Wallets are walked in id order and checkpointed so an interrupted run resumes.
+func (j *NFTTransferSyncJob) Run(ctx context.Context) error {
+	var after uuid.UUID
+	saved, err := j.checkpoints.Load(ctx, nftTransferSyncJobName)
+	if err != nil {
+		return err
+	}
+	if saved != "" {
+		if id, err := uuid.Parse(saved); err == nil {
+			after = id
+		}
+	}
+
+	logger.Info("Starting NFT transfer sync job", "resumeAfter", saved)
+
+	rows, err := j.db.Query(ctx, `SELECT id, address, chain_id FROM wallets WHERE id > $1 AND removed_at IS NULL ORDER BY id`, after)
+	if err != nil {
+		return fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	type walletRef struct {
+		id      uuid.UUID
+		address string
+		chainID int
+	}
+	var wallets []walletRef
+	for rows.Next() {
+		var w walletRef
+		if err := rows.Scan(&w.id, &w.address, &w.chainID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read wallets: %w", err)
+	}
+
+	stored := 0
+	for i, w := range wallets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := j.SyncWallet(ctx, w.id, w.address, w.chainID)
+		stored += n
+		// The checkpoint stays before this wallet, so the run after the reset resumes here
+		if QuotaExhausted(ctx, err, fmt.Sprintf("NFT transfers of %d wallets", len(wallets)-i)) {
+			return nil
+		}
+		if err != nil {
+			logger.Warn("Failed to sync NFT transfers", "address", w.address, "chainID", w.chainID, "error", err)
+		}
+
+		// A cancelled fetch means this wallet wasn't synced; don't skip it next run
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := j.checkpoints.Save(ctx, nftTransferSyncJobName, w.id.String()); err != nil {
+			logger.Warn("Failed to checkpoint NFT transfer sync", "error", err)
+		}
+	}
+
+	if err := j.checkpoints.Clear(ctx, nftTransferSyncJobName); err != nil {
+		logger.Warn("Failed to clear NFT transfer sync checkpoint", "error", err)
+	}
+
+	logger.Info("NFT transfer sync job completed", "wallets", len(wallets), "stored", stored)
+	return nil
+}
+
+// SyncWallet fetches and stores one wallet's NFT transfers, returning how many were stored
+func (j *NFTTransferSyncJob) SyncWallet(ctx context.Context, walletID uuid.UUID, address string, chainID int) (int, error) {
+	transfers, err := j.blockchainService.GetNFTTransfers(ctx, address, chainID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch NFT transfers: %w", err)
+	}
+	n, err := j.pnlService.SyncNFTTransfers(ctx, walletID, transfers)
+	if err != nil {
+		return n, fmt.Errorf("failed to store NFT transfers: %w", err)
+	}
+	return n, nil
+}
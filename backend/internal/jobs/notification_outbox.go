@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// NotificationOutboxJob retries alert notifications that weren't sent when their alert
+// triggered and prunes the ones long since dispatched
+type NotificationOutboxJob struct {
+	outbox services.NotificationOutbox
+}
+
+func NewNotificationOutboxJob(outbox services.NotificationOutbox) *NotificationOutboxJob {
+	return &NotificationOutboxJob{outbox: outbox}
+}
+
+// Run dispatches pending outbox notifications
+func (j *NotificationOutboxJob) Run(ctx context.Context) error {
+	dispatched, err := j.outbox.DispatchDue(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to dispatch outbox notifications: %w", err)
+	}
+	if dispatched > 0 {
+		logger.Info("Dispatched outbox notifications", "count", dispatched)
+	}
+
+	if purged, err := j.outbox.PurgeDispatched(ctx); err != nil {
+		logger.Warn("Failed to prune notification outbox", "error", err)
+	} else if purged > 0 {
+		logger.Info("Pruned notification outbox", "count", purged)
+	}
+	return nil
+}
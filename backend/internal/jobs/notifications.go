@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// NotificationQueueJob delivers notifications that were held back by quiet hours
+type NotificationQueueJob struct {
+	dispatcher services.NotificationDispatcher
+}
+
+func NewNotificationQueueJob(dispatcher services.NotificationDispatcher) *NotificationQueueJob {
+	return &NotificationQueueJob{dispatcher: dispatcher}
+}
+
+// Run delivers all queued notifications that are due
+func (j *NotificationQueueJob) Run(ctx context.Context) error {
+	sent, err := j.dispatcher.DeliverQueued(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to deliver queued notifications: %w", err)
+	}
+
+	if sent > 0 {
+		logger.Info("Delivered queued notifications", "count", sent)
+	}
+	return nil
+}
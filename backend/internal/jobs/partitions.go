@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// monthlyPartitionedTables are the tables partitioned by month; transactions are
+// partitioned by chain, which needs no upkeep
+var monthlyPartitionedTables = []string{"alert_history"}
+
+// partitionLookahead is how far ahead monthly partitions are created, so rows never
+// fall through to the default partition while the worker is down for a while
+const partitionLookahead = 90 * 24 * time.Hour
+
+// PartitionMaintenanceJob creates the coming months' partitions of monthly partitioned
+// tables and drops the ones past retention
+type PartitionMaintenanceJob struct {
+	partitionRepo repos.PartitionRepository
+	retention     time.Duration
+	clock         clock.Clock
+}
+
+// NewPartitionMaintenanceJob keeps partitions whose month ended within retention; 0
+// keeps them all
+func NewPartitionMaintenanceJob(partitionRepo repos.PartitionRepository, retention time.Duration) *PartitionMaintenanceJob {
+	return &PartitionMaintenanceJob{partitionRepo: partitionRepo, retention: retention, clock: clock.System}
+}
+
+func (j *PartitionMaintenanceJob) Run(ctx context.Context) error {
+	now := j.clock.Now().UTC()
+	for _, table := range monthlyPartitionedTables {
+		created, err := j.partitionRepo.EnsureMonthlyPartitions(ctx, table, now, now.Add(partitionLookahead))
+		if err != nil {
+			return fmt.Errorf("failed to maintain partitions: %w", err)
+		}
+		if created > 0 {
+			logger.Info("Created table partitions", "table", table, "count", created)
+		}
+
+		if j.retention <= 0 {
+			continue
+		}
+		dropped, err := j.partitionRepo.DropMonthlyPartitions(ctx, table, now.Add(-j.retention))
+		if err != nil {
+			return fmt.Errorf("failed to drop expired partitions: %w", err)
+		}
+		if dropped > 0 {
+			logger.Info("Dropped expired table partitions", "table", table, "count", dropped)
+		}
+	}
+	return nil
+}
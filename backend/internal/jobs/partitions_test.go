@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePartitionRepo struct {
+	ensured []time.Time
+	dropped []time.Time
+}
+
+func (f *fakePartitionRepo) EnsureMonthlyPartitions(_ context.Context, _ string, from, to time.Time) (int, error) {
+	f.ensured = append(f.ensured, from, to)
+	return 0, nil
+}
+
+func (f *fakePartitionRepo) DropMonthlyPartitions(_ context.Context, _ string, before time.Time) (int, error) {
+	f.dropped = append(f.dropped, before)
+	return 1, nil
+}
+
+func TestPartitionMaintenanceJob(t *testing.T) {
+	now := time.Date(2026, 5, 10, 3, 50, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+
+	repo := &fakePartitionRepo{}
+	job := NewPartitionMaintenanceJob(repo, 30*24*time.Hour)
+	job.clock = fake
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, []time.Time{now, now.Add(partitionLookahead)}, repo.ensured)
+	assert.Equal(t, []time.Time{now.AddDate(0, 0, -30)}, repo.dropped)
+
+	// Without a retention nothing is dropped
+	repo = &fakePartitionRepo{}
+	job = NewPartitionMaintenanceJob(repo, 0)
+	job.clock = fake
+	require.NoError(t, job.Run(context.Background()))
+	assert.Len(t, repo.ensured, 2)
+	assert.Empty(t, repo.dropped)
+}
@@ -0,0 +1,93 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// evaluatePortfolioValueAlerts checks each alert owner's total wallet value from the
+// wallet valuation snapshots
+func (j *AlertEvaluatorJob) evaluatePortfolioValueAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	seen := make(map[uuid.UUID]bool)
+	var userIDs []uuid.UUID
+	for _, alert := range alerts {
+		if !seen[alert.UserID] {
+			seen[alert.UserID] = true
+			userIDs = append(userIDs, alert.UserID)
+		}
+	}
+
+	valuations, err := j.valuationRepo.GetPortfolioValuations(ctx, userIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get portfolio valuations: %w", err)
+	}
+
+	triggered := 0
+	for _, alert := range alerts {
+		valuation, ok := valuations[alert.UserID]
+		if !ok {
+			continue
+		}
+
+		triggeredValue := portfolioValueTrigger(alert.Conditions, valuation)
+		if triggeredValue == nil {
+			continue
+		}
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// portfolioValueTrigger returns what a portfolio value alert fired on, or nil when none of
+// its conditions hold. Thresholds fire on the snapshot that crosses them rather than for
+// as long as the value stays past them.
+func portfolioValueTrigger(conditions models.AlertConditions, valuation *models.PortfolioValuation) map[string]interface{} {
+	triggeredValue := map[string]interface{}{
+		"portfolioValueUsd": valuation.ValueUSD,
+		"recordedAt":        valuation.RecordedAt,
+	}
+	matched := false
+
+	if previous := valuation.PreviousValueUSD; previous != nil {
+		if above := conditions.ValueAbove; above != nil && valuation.ValueUSD > *above && *previous <= *above {
+			triggeredValue["crossedAbove"] = *above
+			matched = true
+		}
+		if below := conditions.ValueBelow; below != nil && valuation.ValueUSD < *below && *previous >= *below {
+			triggeredValue["crossedBelow"] = *below
+			matched = true
+		}
+		if matched {
+			triggeredValue["previousValueUsd"] = *previous
+		}
+	}
+
+	if threshold := conditions.ChangePercent; threshold != nil && valuation.DayAgoValueUSD != nil && *valuation.DayAgoValueUSD > 0 {
+		change := (valuation.ValueUSD - *valuation.DayAgoValueUSD) / *valuation.DayAgoValueUSD * 100
+		if math.Abs(change) >= *threshold {
+			triggeredValue["change24hPercent"] = change
+			triggeredValue["dayAgoValueUsd"] = *valuation.DayAgoValueUSD
+			triggeredValue["threshold"] = *threshold
+			matched = true
+		}
+	}
+
+	if !matched {
+		return nil
+	}
+	return triggeredValue
+}
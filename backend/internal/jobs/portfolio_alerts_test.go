@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortfolioValueTrigger(t *testing.T) {
+	valuation := func(value float64, previous, dayAgo *float64) *models.PortfolioValuation {
+		return &models.PortfolioValuation{
+			UserID:           uuid.New(),
+			ValueUSD:         value,
+			RecordedAt:       time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+			PreviousValueUSD: previous,
+			DayAgoValueUSD:   dayAgo,
+		}
+	}
+
+	t.Run("fires when crossing above", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ValueAbove: floatPtr(100_000)}, valuation(101_000, floatPtr(99_000), nil))
+		require.NotNil(t, got)
+		assert.Equal(t, 100_000.0, got["crossedAbove"])
+		assert.Equal(t, 99_000.0, got["previousValueUsd"])
+	})
+
+	t.Run("stays quiet while already above", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ValueAbove: floatPtr(100_000)}, valuation(105_000, floatPtr(101_000), nil))
+		assert.Nil(t, got)
+	})
+
+	t.Run("fires when crossing below", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ValueBelow: floatPtr(50_000)}, valuation(49_000, floatPtr(50_000), nil))
+		require.NotNil(t, got)
+		assert.Equal(t, 50_000.0, got["crossedBelow"])
+	})
+
+	t.Run("needs a previous snapshot to cross", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ValueBelow: floatPtr(50_000)}, valuation(49_000, nil, nil))
+		assert.Nil(t, got)
+	})
+
+	t.Run("fires on a 24h drop past the percentage", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ChangePercent: floatPtr(10)}, valuation(85_000, floatPtr(86_000), floatPtr(100_000)))
+		require.NotNil(t, got)
+		assert.InDelta(t, -15.0, got["change24hPercent"], 1e-9)
+		assert.Equal(t, 100_000.0, got["dayAgoValueUsd"])
+	})
+
+	t.Run("fires on a 24h rise past the percentage", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ChangePercent: floatPtr(10)}, valuation(112_000, nil, floatPtr(100_000)))
+		require.NotNil(t, got)
+		assert.InDelta(t, 12.0, got["change24hPercent"], 1e-9)
+	})
+
+	t.Run("ignores moves inside the percentage", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ChangePercent: floatPtr(10)}, valuation(95_000, nil, floatPtr(100_000)))
+		assert.Nil(t, got)
+	})
+
+	t.Run("ignores change without a day-old snapshot", func(t *testing.T) {
+		got := portfolioValueTrigger(models.AlertConditions{ChangePercent: floatPtr(10)}, valuation(50_000, floatPtr(100_000), nil))
+		assert.Nil(t, got)
+	})
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// positionAPY is the current APY of the pool a yield position is in
+type positionAPY struct {
+	UserID   uuid.UUID
+	PoolID   string
+	IsActive bool
+	APY      *float64
+}
+
+// evaluatePositionAPYAlerts checks the pool APY behind each alert's yield position.
+// Alerts without a baseline record the current APY as theirs on first evaluation.
+func (j *AlertEvaluatorJob) evaluatePositionAPYAlerts(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	var positionIDs []uuid.UUID
+	for _, alert := range alerts {
+		if id, err := uuid.Parse(alert.Target.Identifier); err == nil {
+			positionIDs = append(positionIDs, id)
+		}
+	}
+	positions, err := j.getPositionAPYs(ctx, positionIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get position APYs: %w", err)
+	}
+
+	triggered := 0
+	for _, alert := range alerts {
+		id, err := uuid.Parse(alert.Target.Identifier)
+		if err != nil {
+			continue
+		}
+		// Positions that closed or aren't the alert owner's are left alone
+		position, ok := positions[id]
+		if !ok || !position.IsActive || position.UserID != alert.UserID || position.APY == nil {
+			continue
+		}
+
+		if alert.Conditions.APYDropPercent != nil && alert.Conditions.BaselineAPY == nil {
+			baseline := *position.APY
+			alert.Conditions.BaselineAPY = &baseline
+			if err := j.alertRepo.Update(ctx, &alert); err != nil {
+				logger.Error("Failed to record position APY baseline",
+					"alertId", alert.ID,
+					"error", err)
+			}
+			continue
+		}
+
+		triggeredValue := positionAPYTrigger(alert.Conditions, *position.APY)
+		if triggeredValue == nil {
+			continue
+		}
+		triggeredValue["positionId"] = alert.Target.Identifier
+		triggeredValue["poolId"] = position.PoolID
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// positionAPYTrigger returns what a position APY alert fired on, or nil when the APY is
+// neither below the floor nor down more than the allowed drop from the baseline
+func positionAPYTrigger(conditions models.AlertConditions, currentAPY float64) map[string]interface{} {
+	triggeredValue := map[string]interface{}{
+		"currentAPY": currentAPY,
+	}
+	matched := false
+
+	if conditions.MinAPR != nil && currentAPY < *conditions.MinAPR {
+		triggeredValue["minAPR"] = *conditions.MinAPR
+		triggeredValue["reason"] = "below_min_apr"
+		matched = true
+	}
+
+	if conditions.APYDropPercent != nil && conditions.BaselineAPY != nil && *conditions.BaselineAPY > 0 {
+		drop := (*conditions.BaselineAPY - currentAPY) / *conditions.BaselineAPY * 100
+		if drop >= *conditions.APYDropPercent {
+			triggeredValue["baselineAPY"] = *conditions.BaselineAPY
+			triggeredValue["dropPercent"] = drop
+			triggeredValue["reason"] = "apy_drop"
+			matched = true
+		}
+	}
+
+	if !matched {
+		return nil
+	}
+	return triggeredValue
+}
+
+// getPositionAPYs returns each position found with its pool's current APY, keyed by position ID
+func (j *AlertEvaluatorJob) getPositionAPYs(ctx context.Context, positionIDs []uuid.UUID) (map[uuid.UUID]positionAPY, error) {
+	positions := make(map[uuid.UUID]positionAPY, len(positionIDs))
+	if len(positionIDs) == 0 {
+		return positions, nil
+	}
+
+	rows, err := j.db.Query(ctx, `
+		SELECT yp.id, yp.user_id, p.pool_id, COALESCE(yp.is_active, TRUE), p.apy::float8
+		FROM yield_positions yp
+		JOIN yield_pools p ON p.id = yp.pool_id
+		WHERE yp.id = ANY($1)`,
+		positionIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var position positionAPY
+		if err := rows.Scan(&id, &position.UserID, &position.PoolID, &position.IsActive, &position.APY); err != nil {
+			return nil, err
+		}
+		positions[id] = position
+	}
+
+	return positions, rows.Err()
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionAPYTrigger(t *testing.T) {
+	t.Run("fires below the floor", func(t *testing.T) {
+		got := positionAPYTrigger(models.AlertConditions{MinAPR: floatPtr(4)}, 3.5)
+		require.NotNil(t, got)
+		assert.Equal(t, "below_min_apr", got["reason"])
+		assert.Equal(t, 4.0, got["minAPR"])
+	})
+
+	t.Run("fires on a relative drop from the baseline", func(t *testing.T) {
+		got := positionAPYTrigger(models.AlertConditions{APYDropPercent: floatPtr(25), BaselineAPY: floatPtr(8)}, 5.6)
+		require.NotNil(t, got)
+		assert.Equal(t, "apy_drop", got["reason"])
+		assert.InDelta(t, 30.0, got["dropPercent"], 1e-9)
+	})
+
+	t.Run("ignores drops inside the allowance", func(t *testing.T) {
+		got := positionAPYTrigger(models.AlertConditions{APYDropPercent: floatPtr(25), BaselineAPY: floatPtr(8), MinAPR: floatPtr(2)}, 6.5)
+		assert.Nil(t, got)
+	})
+
+	t.Run("ignores APY rises", func(t *testing.T) {
+		got := positionAPYTrigger(models.AlertConditions{APYDropPercent: floatPtr(10), BaselineAPY: floatPtr(8)}, 12)
+		assert.Nil(t, got)
+	})
+
+	t.Run("needs a baseline for relative drops", func(t *testing.T) {
+		got := positionAPYTrigger(models.AlertConditions{APYDropPercent: floatPtr(10)}, 0.1)
+		assert.Nil(t, got)
+	})
+}
@@ -0,0 +1,195 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PriceTier is how often a token's price is refreshed
+type PriceTier string
+
+const (
+	// PriceTierHot tokens are refreshed on every run
+	PriceTierHot PriceTier = "hot"
+	// PriceTierWarm tokens are refreshed every priceWarmInterval
+	PriceTierWarm PriceTier = "warm"
+	// PriceTierCold tokens are refreshed every priceColdInterval
+	PriceTierCold PriceTier = "cold"
+)
+
+const (
+	priceWarmInterval = 30 * time.Minute
+	priceColdInterval = 6 * time.Hour
+
+	// priceHotHoldings is how many of the most valuable held tokens are hot
+	priceHotHoldings = 50
+	// priceHotMarketCap and priceWarmMarketCap are the market caps (USD) at which
+	// tokens nobody holds are still hot or warm
+	priceHotMarketCap  = 1_000_000_000
+	priceWarmMarketCap = 50_000_000
+
+	// priceRefreshMaxTokens bounds how many tokens one run prices, hottest first
+	priceRefreshMaxTokens = 250
+)
+
+// priceCandidate is a token the price refresh job may price, with what decides its tier
+type priceCandidate struct {
+	Token        *TokenInfo
+	CoinGeckoID  string
+	HeldUSD      float64 // value held across all wallets at the last known price
+	MarketCap    *float64
+	AlertTarget  bool
+	BoostedUntil *time.Time
+	LastUpdated  *time.Time
+	Tier         PriceTier
+}
+
+// interval returns how long a token in the tier keeps its price
+func (t PriceTier) interval() time.Duration {
+	switch t {
+	case PriceTierHot:
+		return 0
+	case PriceTierWarm:
+		return priceWarmInterval
+	default:
+		return priceColdInterval
+	}
+}
+
+func (t PriceTier) rank() int {
+	switch t {
+	case PriceTierHot:
+		return 0
+	case PriceTierWarm:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// assignPriceTiers sets each candidate's tier. Alert targets, recently viewed tokens,
+// the most valuable holdings and large caps are hot; other holdings and mid caps are
+// warm; the long tail is cold.
+func assignPriceTiers(candidates []*priceCandidate, now time.Time) {
+	held := make([]*priceCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.HeldUSD > 0 {
+			held = append(held, c)
+		}
+	}
+	sort.SliceStable(held, func(a, b int) bool { return held[a].HeldUSD > held[b].HeldUSD })
+	topHoldings := make(map[*priceCandidate]bool)
+	for i := 0; i < len(held) && i < priceHotHoldings; i++ {
+		topHoldings[held[i]] = true
+	}
+
+	for _, c := range candidates {
+		marketCap := 0.0
+		if c.MarketCap != nil {
+			marketCap = *c.MarketCap
+		}
+		switch {
+		case c.AlertTarget,
+			c.BoostedUntil != nil && c.BoostedUntil.After(now),
+			topHoldings[c],
+			marketCap >= priceHotMarketCap:
+			c.Tier = PriceTierHot
+		case c.HeldUSD > 0, marketCap >= priceWarmMarketCap:
+			c.Tier = PriceTierWarm
+		default:
+			c.Tier = PriceTierCold
+		}
+	}
+}
+
+// duePriceRefresh returns the candidates whose price is due at now, hottest and then
+// stalest first, at most limit of them. Tokens never priced are always due.
+func duePriceRefresh(candidates []*priceCandidate, now time.Time, limit int) []*priceCandidate {
+	assignPriceTiers(candidates, now)
+
+	var due []*priceCandidate
+	for _, c := range candidates {
+		if c.LastUpdated == nil || !now.Before(c.LastUpdated.Add(c.Tier.interval())) {
+			due = append(due, c)
+		}
+	}
+	sort.SliceStable(due, func(a, b int) bool {
+		if due[a].Tier != due[b].Tier {
+			return due[a].Tier.rank() < due[b].Tier.rank()
+		}
+		if due[a].LastUpdated == nil || due[b].LastUpdated == nil {
+			return due[a].LastUpdated == nil && due[b].LastUpdated != nil
+		}
+		return due[a].LastUpdated.Before(*due[b].LastUpdated)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due
+}
+
+// getPriceCandidates loads every token CoinGecko can price, with its holdings, alert
+// targeting and refresh state. Tokens enriched with a CoinGecko ID use it; others fall
+// back to the symbol mapping.
+func (j *PriceRefreshJob) getPriceCandidates(ctx context.Context) ([]*priceCandidate, error) {
+	rows, err := j.db.Query(ctx, `
+		WITH held AS (
+			SELECT b.token_id, SUM(COALESCE(b.balance_usd, 0)) AS held_usd
+			FROM balances b
+			WHERE b.balance > 0
+			GROUP BY b.token_id
+		),
+		alerted AS (
+			SELECT DISTINCT LOWER(a.target->>'identifier') AS address, (a.target->>'chainId')::int AS chain_id
+			FROM alerts a
+			WHERE a.status = 'active' AND a.target->>'type' = 'token'
+		)
+		SELECT t.id, t.address, t.chain_id, t.symbol, t.name,
+		       m.coingecko_id, t.market_cap, t.price_boosted_until, p.updated_at,
+		       h.token_id IS NOT NULL, COALESCE(h.held_usd, 0),
+		       al.address IS NOT NULL
+		FROM tokens t
+		LEFT JOIN token_prices_latest p ON p.token_id = t.id
+		LEFT JOIN token_metadata m ON m.token_id = t.id AND NOT m.not_found
+		LEFT JOIN held h ON h.token_id = t.id
+		LEFT JOIN alerted al ON al.address = LOWER(t.address) AND al.chain_id = t.chain_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*priceCandidate
+	for rows.Next() {
+		var token TokenInfo
+		var c priceCandidate
+		var coinGeckoID *string
+		var isHeld bool
+		if err := rows.Scan(&token.ID, &token.Address, &token.ChainID, &token.Symbol, &token.Name,
+			&coinGeckoID, &c.MarketCap, &c.BoostedUntil, &c.LastUpdated,
+			&isHeld, &c.HeldUSD, &c.AlertTarget); err != nil {
+			return nil, fmt.Errorf("failed to scan price candidate: %w", err)
+		}
+
+		if coinGeckoID != nil && *coinGeckoID != "" {
+			c.CoinGeckoID = *coinGeckoID
+		} else {
+			c.CoinGeckoID = j.getCoinGeckoID(token.Symbol)
+		}
+		if c.CoinGeckoID == "" {
+			continue
+		}
+		// Held tokens without a USD value yet still count as held
+		if isHeld && c.HeldUSD <= 0 {
+			c.HeldUSD = heldWithoutValue
+		}
+		c.Token = &token
+		candidates = append(candidates, &c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// heldWithoutValue ranks held tokens that have never been priced below every valued holding
+const heldWithoutValue = 1e-9
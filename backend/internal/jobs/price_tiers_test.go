@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignPriceTiers(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	largeCap, midCap, smallCap := 5e9, 2e8, 1e6
+	viewedUntil, viewedBefore := now.Add(10*time.Minute), now.Add(-time.Minute)
+
+	candidates := map[string]*priceCandidate{
+		"alerted":   {AlertTarget: true},
+		"viewed":    {BoostedUntil: &viewedUntil},
+		"expired":   {BoostedUntil: &viewedBefore},
+		"held":      {HeldUSD: 250},
+		"large-cap": {MarketCap: &largeCap},
+		"mid-cap":   {MarketCap: &midCap},
+		"small-cap": {MarketCap: &smallCap},
+	}
+	var list []*priceCandidate
+	for _, c := range candidates {
+		list = append(list, c)
+	}
+	assignPriceTiers(list, now)
+
+	assert.Equal(t, PriceTierHot, candidates["alerted"].Tier)
+	assert.Equal(t, PriceTierHot, candidates["viewed"].Tier)
+	assert.Equal(t, PriceTierCold, candidates["expired"].Tier)
+	assert.Equal(t, PriceTierHot, candidates["held"].Tier)
+	assert.Equal(t, PriceTierHot, candidates["large-cap"].Tier)
+	assert.Equal(t, PriceTierWarm, candidates["mid-cap"].Tier)
+	assert.Equal(t, PriceTierCold, candidates["small-cap"].Tier)
+}
+
+func TestAssignPriceTiersOnlyTopHoldingsAreHot(t *testing.T) {
+	var list []*priceCandidate
+	for i := 0; i < priceHotHoldings+10; i++ {
+		list = append(list, &priceCandidate{HeldUSD: float64(i + 1)})
+	}
+	assignPriceTiers(list, time.Now())
+
+	for i, c := range list {
+		want := PriceTierWarm
+		if i >= 10 {
+			want = PriceTierHot
+		}
+		assert.Equal(t, want, c.Tier, "holding worth %v", c.HeldUSD)
+	}
+}
+
+func TestDuePriceRefresh(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time { at := now.Add(-d); return &at }
+	midCap := 2e8
+
+	hot := &priceCandidate{Token: &TokenInfo{Symbol: "HOT"}, AlertTarget: true, LastUpdated: ago(time.Minute)}
+	warmFresh := &priceCandidate{Token: &TokenInfo{Symbol: "WARM1"}, MarketCap: &midCap, LastUpdated: ago(10 * time.Minute)}
+	warmStale := &priceCandidate{Token: &TokenInfo{Symbol: "WARM2"}, MarketCap: &midCap, LastUpdated: ago(45 * time.Minute)}
+	coldFresh := &priceCandidate{Token: &TokenInfo{Symbol: "COLD1"}, LastUpdated: ago(2 * time.Hour)}
+	coldStale := &priceCandidate{Token: &TokenInfo{Symbol: "COLD2"}, LastUpdated: ago(7 * time.Hour)}
+	coldNew := &priceCandidate{Token: &TokenInfo{Symbol: "COLD3"}}
+
+	due := duePriceRefresh([]*priceCandidate{coldStale, coldFresh, warmFresh, coldNew, warmStale, hot}, now, 10)
+
+	var symbols []string
+	for _, c := range due {
+		symbols = append(symbols, c.Token.Symbol)
+	}
+	// Hot first, then warm, then cold with never-priced tokens ahead of stale ones
+	assert.Equal(t, []string{"HOT", "WARM2", "COLD3", "COLD2"}, symbols)
+}
+
+func TestDuePriceRefreshLimit(t *testing.T) {
+	var list []*priceCandidate
+	for i := 0; i < 20; i++ {
+		list = append(list, &priceCandidate{Token: &TokenInfo{Symbol: fmt.Sprint(i)}})
+	}
+	list[15].AlertTarget = true
+
+	due := duePriceRefresh(list, time.Now(), 5)
+	require.Len(t, due, 5)
+	assert.Equal(t, "15", due[0].Token.Symbol, "hot tokens are kept when the run is capped")
+}
@@ -2,20 +2,33 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
 	"github.com/defi-dashboard/backend/pkg/external"
 	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	// yieldPoolSyncBatchSize is how many pools are committed per checkpoint
+	yieldPoolSyncBatchSize = 100
+
+	yieldPoolSyncJobName = "yield-pool-sync"
+)
+
 type PriceRefreshJob struct {
 	db              *pgxpool.Pool
 	coinGeckoClient *external.CoinGeckoClient
 	defiLlamaClient *external.DefiLlamaClient
+	checkpoints     *CheckpointStore
+	clock           clock.Clock
 }
 
 func NewPriceRefreshJob(db *pgxpool.Pool, cgClient *external.CoinGeckoClient, dlClient *external.DefiLlamaClient) *PriceRefreshJob {
@@ -23,6 +36,8 @@ func NewPriceRefreshJob(db *pgxpool.Pool, cgClient *external.CoinGeckoClient, dl
 		db:              db,
 		coinGeckoClient: cgClient,
 		defiLlamaClient: dlClient,
+		checkpoints:     NewCheckpointStore(db),
+		clock:           clock.System,
 	}
 }
 
@@ -30,9 +45,9 @@ func NewPriceRefreshJob(db *pgxpool.Pool, cgClient *external.CoinGeckoClient, dl
 func (j *PriceRefreshJob) Run(ctx context.Context) error {
 	logger.Info("Starting price refresh job")
 
-	// Run price updates and yield updates concurrently
+	// Run price updates, yield updates and the trending list concurrently
 	var wg sync.WaitGroup
-	errChan := make(chan error, 2)
+	errChan := make(chan error, 3)
 
 	// Update token prices
 	wg.Add(1)
@@ -52,6 +67,15 @@ func (j *PriceRefreshJob) Run(ctx context.Context) error {
 		}
 	}()
 
+	// Update trending coins
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := j.updateTrendingCoins(ctx); err != nil {
+			errChan <- fmt.Errorf("trending coin update failed: %w", err)
+		}
+	}()
+
 	// Wait for all updates to complete
 	wg.Wait()
 	close(errChan)
@@ -71,51 +95,57 @@ func (j *PriceRefreshJob) Run(ctx context.Context) error {
 	return nil
 }
 
-// updateTokenPrices fetches and updates token prices from CoinGecko
+// updateTokenPrices fetches and updates token prices from CoinGecko. Only tokens due in
+// their tier are priced, so the long tail doesn't spend quota on every run.
 func (j *PriceRefreshJob) updateTokenPrices(ctx context.Context) error {
-	// Get list of tokens to update from database
-	tokens, err := j.getActiveTokens(ctx)
+	candidates, err := j.getPriceCandidates(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get active tokens: %w", err)
+		return fmt.Errorf("failed to get price candidates: %w", err)
 	}
 
-	if len(tokens) == 0 {
-		logger.Warn("No active tokens found to update")
+	if len(candidates) == 0 {
+		logger.Warn("No priceable tokens found to update")
 		return nil
 	}
 
-	// Map tokens to CoinGecko IDs
-	var tokenIDs []string
-	tokenMap := make(map[string]*TokenInfo)
-	
-	for _, token := range tokens {
-		cgID := j.getCoinGeckoID(token.Symbol)
-		if cgID != "" {
-			tokenIDs = append(tokenIDs, cgID)
-			tokenMap[cgID] = token
-		}
+	due := duePriceRefresh(candidates, j.clock.Now(), priceRefreshMaxTokens)
+	if len(due) == 0 {
+		logger.Info("No token prices due", "total", len(candidates))
+		return nil
 	}
 
-	if len(tokenIDs) == 0 {
-		logger.Warn("No valid CoinGecko IDs found")
-		return nil
+	// Several tokens (the same asset on different chains) can share a CoinGecko ID
+	var tokenIDs []string
+	tokenMap := make(map[string][]*priceCandidate)
+	tiers := make(map[PriceTier]int)
+	for _, candidate := range due {
+		if _, seen := tokenMap[candidate.CoinGeckoID]; !seen {
+			tokenIDs = append(tokenIDs, candidate.CoinGeckoID)
+		}
+		tokenMap[candidate.CoinGeckoID] = append(tokenMap[candidate.CoinGeckoID], candidate)
+		tiers[candidate.Tier]++
 	}
 
 	// Fetch prices from CoinGecko with retry
 	var prices external.PriceResponse
 	for i := 0; i < 3; i++ {
 		prices, err = j.coinGeckoClient.GetTokenPrices(ctx, tokenIDs)
-		if err == nil {
+		if _, exhausted := external.AsQuotaError(err); err == nil || exhausted {
 			break
 		}
 		if i < 2 {
 			logger.Warn("CoinGecko API call failed, retrying", 
 				"attempt", i+1, 
 				"error", err)
-			time.Sleep(time.Duration(i+1) * time.Second)
+			if err := sleepCtx(ctx, time.Duration(i+1)*time.Second); err != nil {
+				return err
+			}
 		}
 	}
 
+	if QuotaExhausted(ctx, err, fmt.Sprintf("pricing %d tokens", len(due))) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to fetch prices after retries: %w", err)
 	}
@@ -129,41 +159,56 @@ func (j *PriceRefreshJob) updateTokenPrices(ctx context.Context) error {
 
 	updated := 0
 	for cgID, priceData := range prices {
-		token, exists := tokenMap[cgID]
-		if !exists {
-			continue
-		}
-
-		// Update token price
-		_, err = tx.Exec(ctx, `
-			UPDATE tokens 
-			SET price_usd = $1, 
-				price_change_24h = $2,
-				last_updated = NOW(),
-				updated_at = NOW()
-			WHERE address = $3 AND chain_id = $4`,
-			priceData.USD, priceData.USD24hChange, token.Address, token.ChainID)
-		
-		if err != nil {
-			logger.Error("Failed to update token price",
+		for _, candidate := range tokenMap[cgID] {
+			token := candidate.Token
+
+			// Update token price
+			_, err = tx.Exec(ctx, `
+				INSERT INTO token_prices_latest (token_id, price_usd, price_change_24h, updated_at)
+				VALUES ($1, $2, $3, NOW())
+				ON CONFLICT (token_id) DO UPDATE SET
+					price_usd = EXCLUDED.price_usd,
+					price_change_24h = EXCLUDED.price_change_24h,
+					updated_at = EXCLUDED.updated_at`,
+				token.ID, priceData.USD, priceData.USD24hChange)
+			
+			if err != nil {
+				logger.Error("Failed to update token price",
+					"token", token.Symbol,
+					"error", err)
+				continue
+			}
+
+			// The tokens row is only touched when the token moves tier
+			_, err = tx.Exec(ctx, `
+				UPDATE tokens SET price_tier = $2
+				WHERE id = $1 AND price_tier IS DISTINCT FROM $2`,
+				token.ID, string(candidate.Tier))
+			if err != nil {
+				logger.Error("Failed to update token price tier",
+					"token", token.Symbol,
+					"error", err)
+			}
+
+			// Insert price history record
+			_, err = tx.Exec(ctx, `
+				INSERT INTO price_history (token_id, price_usd, timestamp, source)
+				VALUES ($1, $2, NOW(), 'coingecko')`,
+				token.ID, priceData.USD)
+			
+			if err != nil {
+				logger.Error("Failed to insert price history",
+					"token", token.Symbol,
+					"error", err)
+			}
+
+			logger.DebugSampled("token-price-update", "Token price updated",
 				"token", token.Symbol,
-				"error", err)
-			continue
+				"chainId", token.ChainID,
+				"priceUsd", priceData.USD,
+				"tier", candidate.Tier)
+			updated++
 		}
-
-		// Insert price history record
-		_, err = tx.Exec(ctx, `
-			INSERT INTO price_history (token_id, price_usd, timestamp, source)
-			VALUES ($1, $2, NOW(), 'coingecko')`,
-			token.ID, priceData.USD)
-		
-		if err != nil {
-			logger.Error("Failed to insert price history",
-				"token", token.Symbol,
-				"error", err)
-		}
-
-		updated++
 	}
 
 	if err = tx.Commit(ctx); err != nil {
@@ -171,12 +216,65 @@ func (j *PriceRefreshJob) updateTokenPrices(ctx context.Context) error {
 	}
 
 	logger.Info("Token prices updated", 
-		"total", len(tokens),
+		"total", len(candidates),
+		"due", len(due),
+		"hot", tiers[PriceTierHot],
+		"warm", tiers[PriceTierWarm],
+		"cold", tiers[PriceTierCold],
 		"updated", updated)
 
 	return nil
 }
 
+// updateTrendingCoins replaces the stored trending coins with CoinGecko's current list
+func (j *PriceRefreshJob) updateTrendingCoins(ctx context.Context) error {
+	coins, err := j.coinGeckoClient.GetTrendingCoins(ctx)
+	if QuotaExhausted(ctx, err, "the trending coin list") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch trending coins: %w", err)
+	}
+	// An empty answer more likely means trouble upstream than nothing trending
+	if len(coins) == 0 {
+		logger.Warn("No trending coins returned, keeping the previous list")
+		return nil
+	}
+
+	tx, err := j.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM trending_coins`); err != nil {
+		return fmt.Errorf("failed to clear trending coins: %w", err)
+	}
+	for i, coin := range coins {
+		var marketCapRank *int
+		if coin.MarketCapRank > 0 {
+			marketCapRank = &coin.MarketCapRank
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO trending_coins (
+				rank, coingecko_id, symbol, name, market_cap_rank,
+				thumb_url, price_usd, price_change_24h, updated_at
+			) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NOW())`,
+			i+1, coin.ID, strings.ToUpper(coin.Symbol), coin.Name, marketCapRank,
+			coin.Thumb, coin.PriceUSD, coin.PriceChange24h)
+		if err != nil {
+			return fmt.Errorf("failed to insert trending coin %s: %w", coin.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logger.Info("Trending coins updated", "count", len(coins))
+	return nil
+}
+
 // updateYieldPools fetches and updates yield pool data from DefiLlama
 func (j *PriceRefreshJob) updateYieldPools(ctx context.Context) error {
 	// Fetch yield pools from DefiLlama with retry
@@ -192,7 +290,9 @@ func (j *PriceRefreshJob) updateYieldPools(ctx context.Context) error {
 			logger.Warn("DefiLlama API call failed, retrying",
 				"attempt", i+1,
 				"error", err)
-			time.Sleep(time.Duration(i+1) * time.Second)
+			if err := sleepCtx(ctx, time.Duration(i+1)*time.Second); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -222,14 +322,8 @@ func (j *PriceRefreshJob) updateYieldPools(ctx context.Context) error {
 		"stargate":    true,
 	}
 
-	// Begin transaction
-	tx, err := j.db.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	updated := 0
+	// Filter up front and walk pools in a stable order so progress can be checkpointed
+	var eligible []external.YieldPool
 	for _, pool := range pools {
 		// Skip unsupported chains or protocols
 		if !supportedChains[pool.Chain] || !supportedProtocols[strings.ToLower(pool.Project)] {
@@ -241,23 +335,94 @@ func (j *PriceRefreshJob) updateYieldPools(ctx context.Context) error {
 			continue
 		}
 
-		// Upsert yield pool data
+		eligible = append(eligible, pool)
+	}
+	sort.Slice(eligible, func(a, b int) bool { return eligible[a].Pool < eligible[b].Pool })
+
+	resumeAfter, err := j.checkpoints.Load(ctx, yieldPoolSyncJobName)
+	if err != nil {
+		return err
+	}
+	if resumeAfter != "" {
+		remaining := yieldPoolsAfter(eligible, resumeAfter)
+		logger.Info("Resuming yield pool sync from checkpoint", "after", resumeAfter, "skipped", len(eligible)-len(remaining))
+		eligible = remaining
+	}
+
+	updated := 0
+	for start := 0; start < len(eligible); start += yieldPoolSyncBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		end := start + yieldPoolSyncBatchSize
+		if end > len(eligible) {
+			end = len(eligible)
+		}
+		batch := eligible[start:end]
+
+		n, err := j.upsertYieldPools(ctx, batch)
+		if err != nil {
+			return err
+		}
+		updated += n
+
+		if err := j.checkpoints.Save(ctx, yieldPoolSyncJobName, batch[len(batch)-1].Pool); err != nil {
+			logger.Warn("Failed to checkpoint yield pool sync", "error", err)
+		}
+	}
+
+	if err := j.checkpoints.Clear(ctx, yieldPoolSyncJobName); err != nil {
+		logger.Warn("Failed to clear yield pool sync checkpoint", "error", err)
+	}
+
+	logger.Info("Yield pools updated",
+		"total", len(pools),
+		"updated", updated)
+
+	return nil
+}
+
+// upsertYieldPools writes one batch of pools in a single transaction
+func (j *PriceRefreshJob) upsertYieldPools(ctx context.Context, pools []external.YieldPool) (int, error) {
+	tx, err := j.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	updated := 0
+	for _, pool := range pools {
+		rewardsJSON, _ := json.Marshal(map[string]interface{}{
+			models.PoolMetadataRewards: rewardBreakdown(pool),
+		})
+
+		// Upsert yield pool data, replacing only the rewards entry of the metadata, and
+		// snapshot its APY and TVL into the pool's history
 		_, err = tx.Exec(ctx, `
+			WITH upserted AS (
 			INSERT INTO yield_pools (
 				pool_id, protocol, pool_name, chain, symbol,
 				tvl_usd, apy, apy_base, apy_reward,
-				il_7d, stable_coin, updated_at
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+				il_7d, stable_coin, metadata, apy_pct_1d, apy_pct_7d, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW())
 			ON CONFLICT (pool_id) DO UPDATE SET
 				tvl_usd = $6,
 				apy = $7,
 				apy_base = $8,
 				apy_reward = $9,
 				il_7d = $10,
-				updated_at = NOW()`,
+				metadata = COALESCE(yield_pools.metadata, '{}'::jsonb) || $12::jsonb,
+				apy_pct_1d = $13,
+				apy_pct_7d = $14,
+				updated_at = NOW()
+			RETURNING pool_id, apy, tvl_usd
+			)
+			INSERT INTO yield_pool_history (pool_id, apy, tvl_usd)
+			SELECT pool_id, apy, tvl_usd FROM upserted`,
 			pool.Pool, pool.Project, pool.Symbol, pool.Chain, pool.Symbol,
 			pool.TVL, pool.APY, pool.APYBase, pool.APYReward,
-			pool.IL7d, pool.StableCoin)
+			pool.IL7d, pool.StableCoin, rewardsJSON, pool.APYPct1D, pool.APYPct7D)
 
 		if err != nil {
 			logger.Error("Failed to upsert yield pool",
@@ -270,45 +435,28 @@ func (j *PriceRefreshJob) updateYieldPools(ctx context.Context) error {
 	}
 
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	logger.Info("Yield pools updated",
-		"total", len(pools),
-		"updated", updated)
-
-	return nil
+	return updated, nil
 }
 
-// getActiveTokens retrieves tokens that need price updates
-func (j *PriceRefreshJob) getActiveTokens(ctx context.Context) ([]*TokenInfo, error) {
-	rows, err := j.db.Query(ctx, `
-		SELECT DISTINCT t.id, t.address, t.chain_id, t.symbol, t.name
-		FROM tokens t
-		INNER JOIN balances b ON b.token_id = t.id
-		WHERE b.balance > 0
-			OR t.last_updated IS NULL
-			OR t.last_updated < NOW() - INTERVAL '15 minutes'
-		ORDER BY t.market_cap DESC NULLS LAST
-		LIMIT 100`)
-	
-	if err != nil {
-		return nil, err
+// rewardBreakdown lists the tokens paying a pool's reward APY. DefiLlama only reports
+// the total, so it's attributed to a token only when there is just one.
+func rewardBreakdown(pool external.YieldPool) []models.RewardAPR {
+	rewards := []models.RewardAPR{}
+	if pool.APYReward <= 0 {
+		return rewards
 	}
-	defer rows.Close()
-
-	var tokens []*TokenInfo
-	for rows.Next() {
-		var token TokenInfo
-		err := rows.Scan(&token.ID, &token.Address, &token.ChainID, &token.Symbol, &token.Name)
-		if err != nil {
-			logger.Error("Failed to scan token", "error", err)
-			continue
+	for _, token := range pool.RewardTokens {
+		reward := models.RewardAPR{Token: strings.ToLower(token), Source: models.RewardSourceDefiLlama}
+		if len(pool.RewardTokens) == 1 {
+			apr := pool.APYReward
+			reward.APR = &apr
 		}
-		tokens = append(tokens, &token)
+		rewards = append(rewards, reward)
 	}
-
-	return tokens, rows.Err()
+	return rewards
 }
 
 // getCoinGeckoID maps token symbols to CoinGecko IDs
@@ -324,4 +472,23 @@ type TokenInfo struct {
 	ChainID int
 	Symbol  string
 	Name    string
+}
+
+// yieldPoolsAfter returns the pools, sorted by ID, that come after the checkpointed one
+func yieldPoolsAfter(pools []external.YieldPool, checkpoint string) []external.YieldPool {
+	skip := sort.Search(len(pools), func(i int) bool { return pools[i].Pool > checkpoint })
+	return pools[skip:]
+}
+
+// sleepCtx waits for d or until ctx is cancelled, whichever comes first
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
\ No newline at end of file
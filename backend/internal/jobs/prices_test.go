@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardBreakdown(t *testing.T) {
+	single := rewardBreakdown(external.YieldPool{APYReward: 2.5, RewardTokens: []string{"0xABC"}})
+	require.Len(t, single, 1)
+	assert.Equal(t, "0xabc", single[0].Token)
+	require.NotNil(t, single[0].APR)
+	assert.Equal(t, 2.5, *single[0].APR)
+
+	// The total can't be split between several tokens
+	several := rewardBreakdown(external.YieldPool{APYReward: 2.5, RewardTokens: []string{"0xa", "0xb"}})
+	require.Len(t, several, 2)
+	assert.Nil(t, several[0].APR)
+	assert.Nil(t, several[1].APR)
+
+	// Ended emissions may still list their tokens
+	assert.Empty(t, rewardBreakdown(external.YieldPool{RewardTokens: []string{"0xa"}}))
+}
+
+func TestYieldPoolsAfter(t *testing.T) {
+	pools := []external.YieldPool{{Pool: "a"}, {Pool: "c"}, {Pool: "e"}}
+	assert.Len(t, yieldPoolsAfter(pools, ""), 3)
+	assert.Equal(t, []external.YieldPool{{Pool: "e"}}, yieldPoolsAfter(pools, "c"))
+	// A checkpointed pool DefiLlama no longer lists still marks the place
+	assert.Equal(t, []external.YieldPool{{Pool: "c"}, {Pool: "e"}}, yieldPoolsAfter(pools, "b"))
+	assert.Empty(t, yieldPoolsAfter(pools, "e"))
+}
+
+func TestSleepCtx(t *testing.T) {
+	assert.NoError(t, sleepCtx(context.Background(), time.Millisecond))
+
+	// Shutdown doesn't wait out a retry's backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	assert.ErrorIs(t, sleepCtx(ctx, time.Minute), context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
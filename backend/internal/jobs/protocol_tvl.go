@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// protocolTVLRetention is how long hourly TVL snapshots are kept
+const protocolTVLRetention = 365 * 24 * time.Hour
+
+// llamaExtraTVLBuckets are chainTvls keys that are TVL categories rather than chains
+var llamaExtraTVLBuckets = map[string]bool{
+	"staking": true, "pool2": true, "borrowed": true, "doublecounted": true,
+	"liquidstaking": true, "vesting": true, "dcandlsoverlap": true, "offers": true,
+	"treasury": true,
+}
+
+// ProtocolTVLSyncJob records total and per-chain TVL for tracked protocols from DefiLlama
+type ProtocolTVLSyncJob struct {
+	protocolRepo    repos.ProtocolRepository
+	tvlRepo         repos.ProtocolTVLRepository
+	defiLlamaClient *external.DefiLlamaClient
+	clock           clock.Clock
+}
+
+func NewProtocolTVLSyncJob(protocolRepo repos.ProtocolRepository, tvlRepo repos.ProtocolTVLRepository, defiLlamaClient *external.DefiLlamaClient) *ProtocolTVLSyncJob {
+	return &ProtocolTVLSyncJob{
+		protocolRepo:    protocolRepo,
+		tvlRepo:         tvlRepo,
+		defiLlamaClient: defiLlamaClient,
+		clock:           clock.System,
+	}
+}
+
+// Run snapshots TVL for every active protocol DefiLlama lists under the same slug
+func (j *ProtocolTVLSyncJob) Run(ctx context.Context) error {
+	active := true
+	protocols, err := j.protocolRepo.GetAll(ctx, repos.ProtocolFilters{IsActive: &active, SortBy: "name", Limit: 1000})
+	if err != nil {
+		return fmt.Errorf("failed to get protocols: %w", err)
+	}
+	if len(protocols) == 0 {
+		return nil
+	}
+
+	llamaProtocols, err := j.defiLlamaClient.GetProtocols(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch DefiLlama protocols: %w", err)
+	}
+	bySlug := make(map[string]external.LlamaProtocol, len(llamaProtocols))
+	for _, p := range llamaProtocols {
+		bySlug[strings.ToLower(p.Slug)] = p
+	}
+
+	recorded, missing := 0, 0
+	for _, protocol := range protocols {
+		llama, ok := bySlug[strings.ToLower(protocol.Slug)]
+		if !ok {
+			missing++
+			continue
+		}
+
+		snapshot := &models.ProtocolTVLSnapshot{
+			ProtocolID: protocol.ID,
+			TVLUSD:     llama.TVL,
+			ChainTVLs:  chainBreakdown(llama.ChainTVLs),
+		}
+		if err := j.tvlRepo.Record(ctx, snapshot); err != nil {
+			logger.Error("Failed to record protocol TVL", "protocol", protocol.Slug, "error", err)
+			continue
+		}
+		recorded++
+	}
+
+	pruned, err := j.tvlRepo.DeleteOlderThan(ctx, j.clock.Now().Add(-protocolTVLRetention))
+	if err != nil {
+		logger.Warn("Failed to prune protocol TVL history", "error", err)
+	}
+
+	logger.Info("Protocol TVL sync completed", "protocols", len(protocols), "recorded", recorded, "notListed", missing, "pruned", pruned)
+	return nil
+}
+
+// chainBreakdown keeps only per-chain TVL, dropping DefiLlama's category buckets
+// ("staking") and chain-scoped category buckets ("Ethereum-borrowed")
+func chainBreakdown(chainTVLs map[string]float64) map[string]float64 {
+	breakdown := make(map[string]float64, len(chainTVLs))
+	for chain, tvl := range chainTVLs {
+		if strings.Contains(chain, "-") || llamaExtraTVLBuckets[strings.ToLower(chain)] {
+			continue
+		}
+		breakdown[chain] = tvl
+	}
+	return breakdown
+}
@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainBreakdown(t *testing.T) {
+	breakdown := chainBreakdown(map[string]float64{
+		"Ethereum":          1000,
+		"Arbitrum":          250,
+		"Ethereum-borrowed": 400,
+		"Arbitrum-staking":  10,
+		"borrowed":          400,
+		"staking":           10,
+		"pool2":             5,
+	})
+
+	assert.Equal(t, map[string]float64{"Ethereum": 1000, "Arbitrum": 250}, breakdown)
+	assert.Empty(t, chainBreakdown(nil))
+}
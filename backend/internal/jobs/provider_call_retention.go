@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// ProviderCallRetentionJob prunes logged provider calls past their retention
+type ProviderCallRetentionJob struct {
+	providerCallRepo repos.ProviderCallRepository
+	clock            clock.Clock
+}
+
+func NewProviderCallRetentionJob(providerCallRepo repos.ProviderCallRepository) *ProviderCallRetentionJob {
+	return &ProviderCallRetentionJob{providerCallRepo: providerCallRepo, clock: clock.System}
+}
+
+func (j *ProviderCallRetentionJob) Run(ctx context.Context) error {
+	pruned, err := j.providerCallRepo.DeleteBefore(ctx, j.clock.Now().Add(-services.ProviderCallRetention))
+	if err != nil {
+		return fmt.Errorf("failed to prune provider calls: %w", err)
+	}
+
+	if pruned > 0 {
+		logger.Info("Provider call retention cleanup completed", "pruned", pruned)
+	}
+	return nil
+}
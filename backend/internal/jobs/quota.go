@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// ProviderCooldowns remembers which providers' quotas ran out and when they reset, so
+// the scheduler holds back the jobs spending them instead of hitting the limit again
+type ProviderCooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func NewProviderCooldowns() *ProviderCooldowns {
+	return &ProviderCooldowns{until: make(map[string]time.Time)}
+}
+
+// coolDown leaves provider alone until the given time, or a later one already noted
+func (c *ProviderCooldowns) coolDown(provider string, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until.After(c.until[provider]) {
+		c.until[provider] = until
+	}
+}
+
+// Until reports when provider's quota resets, if it's still cooling down at now
+func (c *ProviderCooldowns) Until(provider string, now time.Time) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.until[provider]
+	if !ok || !until.After(now) {
+		delete(c.until, provider)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// quotaRun is a scheduled run's view of the cooldowns, noting the latest reset that held
+// it up so the scheduler can run it again then
+type quotaRun struct {
+	cooldowns *ProviderCooldowns
+	clock     clock.Clock
+
+	mu      sync.Mutex
+	resetAt time.Time
+}
+
+type quotaRunKey struct{}
+
+func (r *quotaRun) note(provider string, resetAt time.Time) {
+	r.cooldowns.coolDown(provider, resetAt)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if resetAt.After(r.resetAt) {
+		r.resetAt = resetAt
+	}
+}
+
+func (r *quotaRun) retryAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resetAt
+}
+
+// QuotaExhausted reports whether err is a provider refusing calls until its quota
+// resets. If it is, the run is marked degraded with skipped, the work left undone, and
+// the provider cools down: the scheduler holds back jobs spending it and runs this job
+// again once it resets. The job should then stop calling the provider, keep its
+// progress and return without an error.
+func QuotaExhausted(ctx context.Context, err error, skipped string) bool {
+	quotaErr, ok := external.AsQuotaError(err)
+	if !ok {
+		return false
+	}
+
+	run, _ := ctx.Value(quotaRunKey{}).(*quotaRun)
+	now := clock.System.Now()
+	if run != nil {
+		now = run.clock.Now()
+	}
+	resetAt := now.Add(quotaErr.ResetAfter())
+	if run != nil {
+		run.note(quotaErr.Provider, resetAt)
+	}
+
+	logger.Warn("Provider quota exhausted, leaving the rest for after it resets",
+		"provider", quotaErr.Provider, "resetAt", resetAt, "skipped", skipped)
+	MarkDegraded(ctx, fmt.Sprintf("%s quota exhausted until %s, skipped %s",
+		quotaErr.Provider, resetAt.UTC().Format(time.RFC3339), skipped))
+	return true
+}
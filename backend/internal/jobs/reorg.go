@@ -0,0 +1,397 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReorgDetectionJob compares the block hashes stored with recently ingested
+// records against the canonical chain. Records from blocks that were reorged
+// out are rolled back, re-ingested, and owners are notified over the realtime feed.
+type ReorgDetectionJob struct {
+	db                *pgxpool.Pool
+	blockchainService *blockchain.BlockchainService
+	pnlService        pnl.Service
+	backfillRepo      repos.WalletBackfillRepository
+	balanceRepo       repos.BalanceRepository
+	publisher         events.Publisher
+}
+
+func NewReorgDetectionJob(db *pgxpool.Pool, blockchainService *blockchain.BlockchainService, pnlService pnl.Service, backfillRepo repos.WalletBackfillRepository, balanceRepo repos.BalanceRepository, publisher events.Publisher) *ReorgDetectionJob {
+	return &ReorgDetectionJob{
+		db:                db,
+		blockchainService: blockchainService,
+		pnlService:        pnlService,
+		backfillRepo:      backfillRepo,
+		balanceRepo:       balanceRepo,
+		publisher:         publisher,
+	}
+}
+
+// maxCorrectionHashes caps the hashes listed per correction to keep events within NOTIFY's payload limit
+const maxCorrectionHashes = 25
+
+// ReorgCorrection is the realtime event payload sent to owners of rolled back records
+type ReorgCorrection struct {
+	ChainID                int      `json:"chain_id"`
+	BlockNumber            int64    `json:"block_number"`
+	StaleHash              string   `json:"stale_hash"`
+	CanonicalHash          string   `json:"canonical_hash"`
+	RemovedTransactions    []string `json:"removed_transactions"`
+	RemovedNFTTransfers    []string `json:"removed_nft_transfers"`
+	RemovedBalances        int      `json:"removed_balances"`
+	ReingestedTransactions int      `json:"reingested_transactions"`
+	ReingestedBalances     int      `json:"reingested_balances"`
+	ReingestedTransfers    int      `json:"reingested_nft_transfers"`
+	// Truncated is set when more hashes were removed than are listed
+	Truncated bool `json:"truncated"`
+}
+
+type storedBlock struct {
+	number int64
+	hash   string
+}
+
+type affectedWallet struct {
+	id      uuid.UUID
+	userID  uuid.UUID
+	address string
+}
+
+// rollback is what was removed for one reorged block
+type rollback struct {
+	wallets        []affectedWallet
+	transactions   []string
+	nftTransfers   []string
+	removedBalance int
+}
+
+// Run checks every chain with tracked wallets
+func (j *ReorgDetectionJob) Run(ctx context.Context) error {
+	rows, err := j.db.Query(ctx, `SELECT DISTINCT chain_id FROM wallets WHERE removed_at IS NULL ORDER BY chain_id`)
+	if err != nil {
+		return fmt.Errorf("failed to get chains: %w", err)
+	}
+	chainIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("failed to read chains: %w", err)
+	}
+
+	reorgs := 0
+	for _, chainID := range chainIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := j.checkChain(ctx, chainID)
+		reorgs += n
+		if err != nil {
+			logger.Error("Reorg check failed", "chainID", chainID, "error", err)
+		}
+	}
+
+	if reorgs > 0 {
+		logger.Info("Reorg detection job completed", "chains", len(chainIDs), "reorgedBlocks", reorgs)
+	}
+	return nil
+}
+
+// RunChain checks one chain, whether or not any wallet tracks it
+func (j *ReorgDetectionJob) RunChain(ctx context.Context, chainID int) error {
+	reorgs, err := j.checkChain(ctx, chainID)
+	if err != nil {
+		return err
+	}
+	logger.Info("Reorg check completed", "chainID", chainID, "reorgedBlocks", reorgs)
+	return nil
+}
+
+// checkChain verifies stored hashes within the chain's reorg window and returns how many blocks were rolled back
+func (j *ReorgDetectionJob) checkChain(ctx context.Context, chainID int) (int, error) {
+	head, err := j.blockchainService.GetLatestBlockNumber(ctx, chainID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get chain head: %w", err)
+	}
+
+	blocks, err := j.storedBlocks(ctx, chainID, head-blockchain.ReorgDepth(chainID))
+	if err != nil {
+		return 0, err
+	}
+
+	canonical := make(map[int64]string)
+	reorged := 0
+	for _, b := range blocks {
+		hash, ok := canonical[b.number]
+		if !ok {
+			hash, err = j.blockchainService.GetBlockHash(ctx, chainID, b.number)
+			if err != nil {
+				return reorged, fmt.Errorf("failed to get hash of block %d: %w", b.number, err)
+			}
+			canonical[b.number] = hash
+		}
+		if hash == b.hash {
+			continue
+		}
+
+		logger.Warn("Chain reorg detected", "chainID", chainID, "block", b.number, "staleHash", b.hash, "canonicalHash", hash)
+		if err := j.handleReorg(ctx, chainID, b, hash); err != nil {
+			return reorged, err
+		}
+		reorged++
+	}
+
+	return reorged, nil
+}
+
+// storedBlocks returns the distinct (block, hash) pairs of records newer than afterBlock
+func (j *ReorgDetectionJob) storedBlocks(ctx context.Context, chainID int, afterBlock int64) ([]storedBlock, error) {
+	query := `
+		SELECT block_number, block_hash FROM nft_transfers
+		WHERE chain_id = $1 AND block_number > $2 AND block_hash IS NOT NULL
+		UNION
+		SELECT block_number, block_hash FROM transactions
+		WHERE chain_id = $1 AND block_number > $2 AND block_hash IS NOT NULL
+		UNION
+		SELECT b.block_number, b.block_hash FROM balances b
+		JOIN wallets w ON w.id = b.wallet_id
+		WHERE w.chain_id = $1 AND b.block_number > $2 AND b.block_hash IS NOT NULL
+		ORDER BY block_number`
+
+	rows, err := j.db.Query(ctx, query, chainID, afterBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored block hashes: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []storedBlock
+	for rows.Next() {
+		var b storedBlock
+		if err := rows.Scan(&b.number, &b.hash); err != nil {
+			return nil, fmt.Errorf("failed to scan stored block: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// handleReorg rolls back records from the stale block, re-ingests the affected
+// wallets' transactions, balances and NFT transfers and notifies their owners
+func (j *ReorgDetectionJob) handleReorg(ctx context.Context, chainID int, stale storedBlock, canonicalHash string) error {
+	rb, err := j.rollback(ctx, chainID, stale, canonicalHash)
+	if err != nil {
+		return err
+	}
+
+	// Re-ingest from the provider, which now reports the canonical chain
+	reingested := make(map[uuid.UUID]reingestCounts)
+	for _, w := range rb.wallets {
+		counts := reingested[w.userID]
+		counts.add(j.reingest(ctx, chainID, stale.number, w))
+		reingested[w.userID] = counts
+	}
+
+	removedTransactions, txTruncated := capHashes(rb.transactions)
+	removedNFTTransfers, nftTruncated := capHashes(rb.nftTransfers)
+
+	notified := make(map[uuid.UUID]bool)
+	for _, w := range rb.wallets {
+		if notified[w.userID] {
+			continue
+		}
+		notified[w.userID] = true
+
+		event, err := events.NewEvent(events.TypeChainReorg, w.userID, ReorgCorrection{
+			ChainID:                chainID,
+			BlockNumber:            stale.number,
+			StaleHash:              stale.hash,
+			CanonicalHash:          canonicalHash,
+			RemovedTransactions:    removedTransactions,
+			RemovedNFTTransfers:    removedNFTTransfers,
+			RemovedBalances:        rb.removedBalance,
+			ReingestedTransactions: reingested[w.userID].transactions,
+			ReingestedBalances:     reingested[w.userID].balances,
+			ReingestedTransfers:    reingested[w.userID].nftTransfers,
+			Truncated:              txTruncated || nftTruncated,
+		})
+		if err == nil {
+			err = j.publisher.Publish(ctx, event)
+		}
+		if err != nil {
+			logger.Warn("Failed to publish reorg correction", "userID", w.userID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// reingestCounts is how many records were stored again for a wallet
+type reingestCounts struct {
+	transactions int
+	balances     int
+	nftTransfers int
+}
+
+func (c *reingestCounts) add(other reingestCounts) {
+	c.transactions += other.transactions
+	c.balances += other.balances
+	c.nftTransfers += other.nftTransfers
+}
+
+// reingest stores a wallet's transactions from the stale block on, its current
+// balances and its NFT transfers. Failures are logged and leave the records to the
+// regular refreshes.
+func (j *ReorgDetectionJob) reingest(ctx context.Context, chainID int, fromBlock int64, w affectedWallet) reingestCounts {
+	var counts reingestCounts
+	wallet := &models.Wallet{ID: w.id, UserID: w.userID, Address: w.address, ChainID: chainID}
+
+	transactions, err := j.blockchainService.GetTransactionsInRange(ctx, w.address, chainID, blockchain.BlockRange{From: fromBlock})
+	if err != nil {
+		logger.Warn("Failed to re-ingest transactions after reorg", "address", w.address, "chainID", chainID, "error", err)
+	} else {
+		for _, tx := range transactions {
+			normalizeBackfilledTransaction(tx, w.address)
+		}
+		j.blockchainService.EnrichGasFees(ctx, transactions)
+		if _, err := j.backfillRepo.SaveTransactions(ctx, wallet, transactions); err != nil {
+			logger.Warn("Failed to store re-ingested transactions", "address", w.address, "error", err)
+		} else {
+			counts.transactions = len(transactions)
+		}
+	}
+
+	balances, _, err := j.blockchainService.GetWalletBalancesAtHead(ctx, w.address, chainID)
+	if err != nil {
+		logger.Warn("Failed to re-ingest balances after reorg", "address", w.address, "chainID", chainID, "error", err)
+	} else if _, err := j.balanceRepo.ReplaceWalletBalances(ctx, w.id, chainID, balances); err != nil {
+		logger.Warn("Failed to store re-ingested balances", "address", w.address, "error", err)
+	} else {
+		counts.balances = len(balances)
+	}
+
+	transfers, err := j.blockchainService.GetNFTTransfers(ctx, w.address, chainID)
+	if err != nil {
+		logger.Warn("Failed to re-ingest NFT transfers after reorg", "address", w.address, "chainID", chainID, "error", err)
+		return counts
+	}
+	counts.nftTransfers, err = j.pnlService.SyncNFTTransfers(ctx, w.id, transfers)
+	if err != nil {
+		logger.Warn("Failed to store re-ingested NFT transfers", "address", w.address, "error", err)
+	}
+	return counts
+}
+
+func capHashes(hashes []string) ([]string, bool) {
+	if hashes == nil {
+		return []string{}, false
+	}
+	if len(hashes) > maxCorrectionHashes {
+		return hashes[:maxCorrectionHashes], true
+	}
+	return hashes, false
+}
+
+// rollback deletes every record stored from the stale block and records the reorg, in one transaction
+func (j *ReorgDetectionJob) rollback(ctx context.Context, chainID int, stale storedBlock, canonicalHash string) (*rollback, error) {
+	tx, err := j.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rb := &rollback{}
+
+	// Owners must be resolved before the rows linking them are deleted
+	walletRows, err := tx.Query(ctx, `
+		SELECT DISTINCT w.id, w.user_id, w.address FROM wallets w
+		WHERE w.chain_id = $1 AND (
+			EXISTS (SELECT 1 FROM nft_transfers n
+			        WHERE n.wallet_id = w.id AND n.block_number = $2 AND n.block_hash = $3)
+			OR EXISTS (SELECT 1 FROM user_transactions ut
+			           JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+			           WHERE ut.wallet_id = w.id AND ut.chain_id = $1 AND t.block_number = $2 AND t.block_hash = $3)
+			OR EXISTS (SELECT 1 FROM balances b
+			           WHERE b.wallet_id = w.id AND b.block_number = $2 AND b.block_hash = $3)
+		)`, chainID, stale.number, stale.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected wallets: %w", err)
+	}
+	for walletRows.Next() {
+		var w affectedWallet
+		if err := walletRows.Scan(&w.id, &w.userID, &w.address); err != nil {
+			walletRows.Close()
+			return nil, fmt.Errorf("failed to scan affected wallet: %w", err)
+		}
+		rb.wallets = append(rb.wallets, w)
+	}
+	walletRows.Close()
+	if err := walletRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read affected wallets: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		DELETE FROM nft_transfers
+		WHERE chain_id = $1 AND block_number = $2 AND block_hash = $3
+		RETURNING transaction_hash`, chainID, stale.number, stale.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete stale NFT transfers: %w", err)
+	}
+	if rb.nftTransfers, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+		return nil, fmt.Errorf("failed to delete stale NFT transfers: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, `
+		DELETE FROM transactions
+		WHERE chain_id = $1 AND block_number = $2 AND block_hash = $3
+		RETURNING hash`, chainID, stale.number, stale.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete stale transactions: %w", err)
+	}
+	if rb.transactions, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+		return nil, fmt.Errorf("failed to delete stale transactions: %w", err)
+	}
+
+	for _, table := range []string{"balances", "balance_history"} {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM `+table+` b USING wallets w
+			WHERE w.id = b.wallet_id AND w.chain_id = $1 AND b.block_number = $2 AND b.block_hash = $3`,
+			chainID, stale.number, stale.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete stale %s: %w", table, err)
+		}
+		rb.removedBalance += int(tag.RowsAffected())
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO reorg_events (chain_id, block_number, stale_hash, canonical_hash,
+		                          removed_transactions, removed_nft_transfers, removed_balances)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		chainID, stale.number, stale.hash, canonicalHash,
+		len(rb.transactions), len(rb.nftTransfers), rb.removedBalance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record reorg event: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit reorg rollback: %w", err)
+	}
+
+	logger.Info("Rolled back reorged block",
+		"chainID", chainID,
+		"block", stale.number,
+		"transactions", len(rb.transactions),
+		"nftTransfers", len(rb.nftTransfers),
+		"balances", rb.removedBalance,
+		"wallets", len(rb.wallets))
+
+	return rb, nil
+}
@@ -0,0 +1,223 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/jobgraph"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/robfig/cron/v3"
+)
+
+// RunFunc runs a job, reporting false without running it when another replica holds
+// its lock; the worker's runJob in production
+type RunFunc func(ctx context.Context, name string, run func(context.Context) error) (bool, error)
+
+// jobOutcome is how a job's run in a tick went
+type jobOutcome int
+
+const (
+	outcomeSucceeded jobOutcome = iota
+	outcomeFailed
+	// outcomeSkipped is a job not run because a prerequisite failed or was skipped
+	outcomeSkipped
+	// outcomeElsewhere is a job left to the replica holding its lock, or that already ran
+	// its tick
+	outcomeElsewhere
+)
+
+// tickRun is a job's run in a tick; outcome is set before done is closed
+type tickRun struct {
+	done    chan struct{}
+	outcome jobOutcome
+}
+
+type scheduledTickKey struct{}
+
+// scheduledTick returns the time the job running with ctx was scheduled for, which is
+// the same on every replica. Runs not started by the schedule, like those on startup or
+// on demand, have none.
+func scheduledTick(ctx context.Context) (time.Time, bool) {
+	tick, ok := ctx.Value(scheduledTickKey{}).(time.Time)
+	return tick, ok
+}
+
+// Scheduler runs the jobs of a graph on their schedules. Every second it collects the
+// jobs due and runs them together: jobs without prerequisites due start at once, and a
+// job waits for its prerequisites due in the same tick, in dependency order, and is
+// skipped when one of them fails. Prerequisites not due, or run by another replica, are
+// assumed to be fine. A job that runs out of a provider's quota runs again once it
+// resets, and until then jobs spending that provider are held back.
+type Scheduler struct {
+	graph     *jobgraph.Graph
+	run       RunFunc
+	jobs      map[string]func(context.Context) error
+	cooldowns *ProviderCooldowns
+	clock     clock.Clock
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func NewScheduler(graph *jobgraph.Graph, run RunFunc) *Scheduler {
+	return &Scheduler{
+		graph:     graph,
+		run:       run,
+		jobs:      make(map[string]func(context.Context) error),
+		cooldowns: NewProviderCooldowns(),
+		clock:     clock.System,
+		next:      make(map[string]time.Time),
+	}
+}
+
+// Bind sets the function that runs the named job. Jobs never bound, such as ones whose
+// integration isn't configured, aren't scheduled, and don't hold up their dependents.
+func (s *Scheduler) Bind(name string, fn func(context.Context) error) error {
+	if _, ok := s.graph.Job(name); !ok {
+		return fmt.Errorf("job %s is not in the job graph", name)
+	}
+	s.jobs[name] = fn
+	return nil
+}
+
+// Start schedules the bound jobs on c; runs stop taking new ticks when c is stopped
+func (s *Scheduler) Start(ctx context.Context, c *cron.Cron) error {
+	s.start(s.clock.Now())
+	_, err := c.AddFunc("* * * * * *", func() {
+		s.Tick(ctx, s.clock.Now())
+	})
+	return err
+}
+
+func (s *Scheduler) start(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.jobs {
+		s.next[name] = s.graph.Next(name, now)
+	}
+}
+
+// Tick runs the jobs due by now and waits for them
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	s.runTogether(ctx, s.due(now))
+}
+
+// due returns the jobs due by now with the latest time each was due at, and moves them
+// on to their next time. Jobs spending a provider that's cooling down are moved to when
+// it resets instead.
+func (s *Scheduler) due(now time.Time) map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	due := make(map[string]time.Time)
+	for name, next := range s.next {
+		if next.After(now) {
+			continue
+		}
+		if until, provider, ok := s.coolingDown(name, now); ok {
+			logger.Info("Job held back until provider quota resets", "job", name, "provider", provider, "until", until)
+			s.next[name] = until
+			continue
+		}
+		// A tick that lands late runs the job once, for the latest time it was due
+		tick := next
+		for later := s.graph.Next(name, tick); !later.After(now); later = s.graph.Next(name, later) {
+			tick = later
+		}
+		due[name] = tick
+		s.next[name] = s.graph.Next(name, now)
+	}
+	return due
+}
+
+// coolingDown returns the latest reset of the providers the job spends that are cooling
+// down at now
+func (s *Scheduler) coolingDown(name string, now time.Time) (time.Time, string, bool) {
+	job, _ := s.graph.Job(name)
+	var latest time.Time
+	var latestProvider string
+	for _, provider := range job.Providers {
+		if until, ok := s.cooldowns.Until(provider, now); ok && until.After(latest) {
+			latest, latestProvider = until, provider
+		}
+	}
+	return latest, latestProvider, !latest.IsZero()
+}
+
+// retryAt brings a scheduled job's next run forward to at
+func (s *Scheduler) retryAt(name string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if next, ok := s.next[name]; ok && at.Before(next) {
+		s.next[name] = at
+	}
+}
+
+// RunNow runs the named bound jobs together, in dependency order, and waits for them
+func (s *Scheduler) RunNow(ctx context.Context, names ...string) {
+	jobs := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		jobs[name] = time.Time{}
+	}
+	s.runTogether(ctx, jobs)
+}
+
+// runTogether runs the bound jobs given with the tick each was scheduled for, zero
+// when run outside the schedule, and waits for them
+func (s *Scheduler) runTogether(ctx context.Context, jobs map[string]time.Time) {
+	runs := make(map[string]*tickRun, len(jobs))
+	for name := range jobs {
+		if _, ok := s.jobs[name]; ok {
+			runs[name] = &tickRun{done: make(chan struct{})}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range s.graph.Jobs() {
+		run, ok := runs[job.Name]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		jobCtx := ctx
+		if tick := jobs[job.Name]; !tick.IsZero() {
+			jobCtx = context.WithValue(ctx, scheduledTickKey{}, tick)
+		}
+		go func(ctx context.Context, name string, run *tickRun) {
+			defer wg.Done()
+			defer close(run.done)
+			run.outcome = s.runAfterPrerequisites(ctx, name, runs)
+		}(jobCtx, job.Name, run)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) runAfterPrerequisites(ctx context.Context, name string, runs map[string]*tickRun) jobOutcome {
+	for _, prerequisite := range s.graph.Prerequisites(name) {
+		run, ok := runs[prerequisite]
+		if !ok {
+			continue
+		}
+		<-run.done
+		if run.outcome == outcomeFailed || run.outcome == outcomeSkipped {
+			logger.Warn("Job skipped, prerequisite did not succeed", "job", name, "prerequisite", prerequisite)
+			return outcomeSkipped
+		}
+	}
+
+	quota := &quotaRun{cooldowns: s.cooldowns, clock: s.clock}
+	ran, err := s.run(context.WithValue(ctx, quotaRunKey{}, quota), name, s.jobs[name])
+	if resetAt := quota.retryAt(); !resetAt.IsZero() {
+		s.retryAt(name, resetAt)
+	}
+	switch {
+	case err != nil:
+		return outcomeFailed
+	case !ran:
+		return outcomeElsewhere
+	default:
+		return outcomeSucceeded
+	}
+}
\ No newline at end of file
@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/jobgraph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRunner runs jobs in-process, recording the order they finished in
+type recordingRunner struct {
+	mu       sync.Mutex
+	finished []string
+	// elsewhere are jobs another replica holds the lock for
+	elsewhere map[string]bool
+	// ticks are the ticks jobs ran for, by job
+	ticks map[string]time.Time
+}
+
+func (r *recordingRunner) run(ctx context.Context, name string, fn func(context.Context) error) (bool, error) {
+	if r.elsewhere[name] {
+		return false, nil
+	}
+	err := fn(ctx)
+	r.mu.Lock()
+	r.finished = append(r.finished, name)
+	if tick, ok := scheduledTick(ctx); ok {
+		if r.ticks == nil {
+			r.ticks = make(map[string]time.Time)
+		}
+		r.ticks[name] = tick
+	}
+	r.mu.Unlock()
+	return true, err
+}
+
+func testScheduler(t *testing.T, runner *recordingRunner, failing ...string) *Scheduler {
+	graph, err := jobgraph.New([]jobgraph.Job{
+		{Name: "alerts", Schedule: "0 */5 * * * *", DependsOn: []string{"valuations", "pools"}},
+		{Name: "valuations", Schedule: "0 */5 * * * *", DependsOn: []string{"prices"}},
+		{Name: "prices", Schedule: "0 */10 * * * *"},
+		{Name: "pools", Schedule: "0 55 * * * *"},
+		{Name: "notifications", Schedule: "0 * * * * *"},
+		{Name: "webhooks", Schedule: "0 * * * * *"},
+	})
+	require.NoError(t, err)
+
+	scheduler := NewScheduler(graph, runner.run)
+	for _, name := range []string{"alerts", "valuations", "prices", "pools", "notifications"} {
+		name := name
+		require.NoError(t, scheduler.Bind(name, func(context.Context) error {
+			// Give jobs started out of order the chance to finish first
+			time.Sleep(5 * time.Millisecond)
+			for _, failing := range failing {
+				if failing == name {
+					return errors.New("provider down")
+				}
+			}
+			return nil
+		}))
+	}
+	assert.Error(t, scheduler.Bind("missing", func(context.Context) error { return nil }))
+	return scheduler
+}
+
+func TestSchedulerRunsPrerequisitesFirst(t *testing.T) {
+	runner := &recordingRunner{}
+	scheduler := testScheduler(t, runner)
+
+	scheduler.RunNow(context.Background(), "alerts", "valuations", "prices", "webhooks")
+	assert.Equal(t, []string{"prices", "valuations", "alerts"}, runner.finished,
+		"unbound jobs are ignored, and prerequisites not due don't hold up their dependents")
+}
+
+func TestSchedulerSkipsDependentsOfFailedJobs(t *testing.T) {
+	runner := &recordingRunner{}
+	scheduler := testScheduler(t, runner, "prices")
+
+	scheduler.RunNow(context.Background(), "alerts", "valuations", "prices", "notifications")
+	sort.Strings(runner.finished)
+	assert.Equal(t, []string{"notifications", "prices"}, runner.finished,
+		"valuations is skipped for its failed prerequisite, and alerts for its skipped one")
+
+	runner.finished = nil
+	scheduler.RunNow(context.Background(), "alerts", "valuations")
+	assert.Equal(t, []string{"valuations", "alerts"}, runner.finished, "failures only count in the tick they happen")
+}
+
+func TestSchedulerRunsDependentsOfJobsRunElsewhere(t *testing.T) {
+	runner := &recordingRunner{elsewhere: map[string]bool{"prices": true}}
+	scheduler := testScheduler(t, runner)
+
+	scheduler.RunNow(context.Background(), "valuations", "prices")
+	assert.Equal(t, []string{"valuations"}, runner.finished)
+}
+
+func TestSchedulerTick(t *testing.T) {
+	runner := &recordingRunner{}
+	scheduler := testScheduler(t, runner)
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 3, 30, 0, time.UTC)
+	scheduler.start(start)
+
+	scheduler.Tick(ctx, start.Add(time.Second))
+	assert.Empty(t, runner.finished)
+
+	// Cron ticks land a little after the second
+	scheduler.Tick(ctx, time.Date(2024, 5, 1, 12, 4, 0, 2e6, time.UTC))
+	assert.Equal(t, []string{"notifications"}, runner.finished)
+
+	runner.finished = nil
+	scheduler.Tick(ctx, time.Date(2024, 5, 1, 12, 5, 0, 2e6, time.UTC))
+	sort.Strings(runner.finished)
+	assert.Equal(t, []string{"alerts", "notifications", "valuations"}, runner.finished)
+
+	runner.finished = nil
+	scheduler.Tick(ctx, time.Date(2024, 5, 1, 12, 5, 1, 0, time.UTC))
+	assert.Empty(t, runner.finished, "a job runs once per time it's due")
+
+	runner.finished = nil
+	scheduler.Tick(ctx, time.Date(2024, 5, 1, 12, 10, 0, 2e6, time.UTC))
+	sort.Strings(runner.finished)
+	assert.Equal(t, []string{"alerts", "notifications", "prices", "valuations"}, runner.finished)
+}
+
+func TestSchedulerRunsJobsForTheirTick(t *testing.T) {
+	runner := &recordingRunner{}
+	scheduler := testScheduler(t, runner)
+	ctx := context.Background()
+	scheduler.start(time.Date(2024, 5, 1, 12, 3, 30, 0, time.UTC))
+
+	// Jobs run for the time they were due, whenever the tick lands, so replicas agree
+	tick := time.Date(2024, 5, 1, 12, 5, 0, 0, time.UTC)
+	scheduler.Tick(ctx, tick.Add(700*time.Millisecond))
+	assert.Equal(t, map[string]time.Time{"alerts": tick, "valuations": tick, "notifications": tick}, runner.ticks)
+
+	// Runs outside the schedule have no tick
+	runner.ticks = nil
+	scheduler.RunNow(ctx, "prices")
+	assert.Empty(t, runner.ticks)
+}
+func TestSchedulerHoldsBackJobsUntilQuotaResets(t *testing.T) {
+	graph, err := jobgraph.New([]jobgraph.Job{
+		{Name: "prices", Schedule: "0 */10 * * * *", Providers: []string{external.ProviderCoinGecko}},
+		{Name: "metadata", Schedule: "0 * * * * *", Providers: []string{external.ProviderCoinGecko}},
+		{Name: "notifications", Schedule: "0 * * * * *"},
+	})
+	require.NoError(t, err)
+
+	runner := &recordingRunner{}
+	scheduler := NewScheduler(graph, runner.run)
+	exhausted := true
+	require.NoError(t, scheduler.Bind("prices", func(ctx context.Context) error {
+		if exhausted {
+			exhausted = false
+			quotaErr := &external.QuotaError{Provider: external.ProviderCoinGecko, RetryAfter: 90 * time.Second, Err: errors.New("CoinGecko API error: 429")}
+			assert.True(t, QuotaExhausted(ctx, quotaErr, "pricing 3 tokens"))
+		}
+		return nil
+	}))
+	for _, name := range []string{"metadata", "notifications"} {
+		require.NoError(t, scheduler.Bind(name, func(context.Context) error { return nil }))
+	}
+	ctx := context.Background()
+	tick := func(at time.Time) []string {
+		runner.finished = nil
+		scheduler.clock = clock.NewFake(at)
+		scheduler.Tick(ctx, at)
+		sort.Strings(runner.finished)
+		return runner.finished
+	}
+
+	scheduler.start(time.Date(2024, 5, 1, 12, 9, 30, 0, time.UTC))
+	assert.Equal(t, []string{"metadata", "notifications", "prices"}, tick(time.Date(2024, 5, 1, 12, 10, 0, 0, time.UTC)))
+	assert.Equal(t, []string{"notifications"}, tick(time.Date(2024, 5, 1, 12, 11, 0, 0, time.UTC)),
+		"metadata is held back while CoinGecko cools down")
+	assert.Equal(t, []string{"metadata", "prices"}, tick(time.Date(2024, 5, 1, 12, 11, 30, 0, time.UTC)),
+		"prices runs again once the quota resets, rather than at its next time")
+	assert.Equal(t, []string{"metadata", "notifications"}, tick(time.Date(2024, 5, 1, 12, 12, 0, 0, time.UTC)))
+}
+
+func TestQuotaExhausted(t *testing.T) {
+	notes := &runNotes{}
+	ctx := context.WithValue(context.Background(), runNotesKey{}, notes)
+	assert.False(t, QuotaExhausted(ctx, nil, "nothing"))
+	assert.False(t, QuotaExhausted(ctx, errors.New("provider down"), "nothing"))
+	assert.Empty(t, notes.degradedReason())
+
+	err := fmt.Errorf("failed to fetch NFT transfers: %w", &external.QuotaError{Provider: external.ProviderAlchemy, Err: errors.New("throttled")})
+	assert.True(t, QuotaExhausted(ctx, err, "NFT transfers of 4 wallets"))
+	assert.Regexp(t, `^alchemy quota exhausted until \S+, skipped NFT transfers of 4 wallets$`, notes.degradedReason())
+}
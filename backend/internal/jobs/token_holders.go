@@ -0,0 +1,126 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// tokenHolderBatchSize caps the tokens refreshed per run; each takes two calls
+	tokenHolderBatchSize = 25
+	// tokenHolderMaxAge is how long holder stats are trusted before they are refreshed
+	tokenHolderMaxAge = 24 * time.Hour
+	// tokenHolderTopCount is how many of a token's largest holders are kept
+	tokenHolderTopCount = 10
+	// tokenHolderSource names the explorer holder stats come from
+	tokenHolderSource = "etherscan"
+)
+
+// HolderExplorer reads a token's largest holders and supply from a chain explorer
+type HolderExplorer interface {
+	GetTopHolders(ctx context.Context, chainID int, contract string, limit int) ([]external.TokenHolder, error)
+	GetTokenSupply(ctx context.Context, chainID int, contract string) (string, error)
+}
+
+// TokenHolderJob refreshes how concentrated held tokens' supplies are among their
+// largest holders, from Etherscan
+type TokenHolderJob struct {
+	holderRepo repos.TokenHolderRepository
+	explorer   HolderExplorer
+}
+
+func NewTokenHolderJob(holderRepo repos.TokenHolderRepository, explorer HolderExplorer) *TokenHolderJob {
+	return &TokenHolderJob{
+		holderRepo: holderRepo,
+		explorer:   explorer,
+	}
+}
+
+// Run refreshes a batch of held tokens whose holder stats are missing or stale
+func (j *TokenHolderJob) Run(ctx context.Context) error {
+	tokens, err := j.holderRepo.GetStaleTokens(ctx, tokenHolderMaxAge, tokenHolderBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	refreshed, missing := 0, 0
+	for _, token := range tokens {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		stats, err := j.fetchStats(ctx, token)
+		if errors.Is(err, external.ErrEtherscanNoData) {
+			stats = &models.TokenHolderStats{TokenID: token.ID, Source: tokenHolderSource, NotFound: true}
+			missing++
+		} else if err != nil {
+			logger.Warn("Failed to fetch token holders", "token", token.Symbol, "chainID", token.ChainID, "error", err)
+			continue
+		} else {
+			refreshed++
+		}
+
+		if err := j.holderRepo.Upsert(ctx, stats); err != nil {
+			logger.Error("Failed to store token holders", "token", token.Symbol, "error", err)
+		}
+	}
+
+	logger.Info("Token holder refresh completed", "tokens", len(tokens), "refreshed", refreshed, "notFound", missing)
+	return nil
+}
+
+// fetchStats reads a token's supply and largest holders and works out their shares
+func (j *TokenHolderJob) fetchStats(ctx context.Context, token *models.Token) (*models.TokenHolderStats, error) {
+	supplyRaw, err := j.explorer.GetTokenSupply(ctx, token.ChainID, token.Address)
+	if err != nil {
+		return nil, err
+	}
+	supply, err := amounts.ParseBaseUnits(supplyRaw)
+	if err != nil {
+		return nil, err
+	}
+	if supply.Sign() == 0 {
+		return nil, external.ErrEtherscanNoData
+	}
+
+	holders, err := j.explorer.GetTopHolders(ctx, token.ChainID, token.Address, tokenHolderTopCount)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.TokenHolderStats{
+		TokenID:     token.ID,
+		TotalSupply: supply.String(),
+		TopHolders:  make([]models.TokenHolderShare, 0, len(holders)),
+		Source:      tokenHolderSource,
+	}
+	top := new(big.Int)
+	for i, holder := range holders {
+		if i == tokenHolderTopCount {
+			break
+		}
+		balance, err := amounts.ParseBaseUnits(holder.Balance)
+		if err != nil {
+			return nil, err
+		}
+		top.Add(top, balance)
+		stats.TopHolders = append(stats.TopHolders, models.TokenHolderShare{
+			Address:  holder.Address,
+			Balance:  balance.String(),
+			SharePct: amounts.Percent(balance, supply),
+		})
+	}
+	stats.Top10SharePct = amounts.Percent(top, supply)
+	return stats, nil
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryTokenHolderRepo struct {
+	repos.TokenHolderRepository
+	stale  []*models.Token
+	stored map[uuid.UUID]*models.TokenHolderStats
+}
+
+func (r *memoryTokenHolderRepo) GetStaleTokens(ctx context.Context, olderThan time.Duration, limit int) ([]*models.Token, error) {
+	return r.stale, nil
+}
+
+func (r *memoryTokenHolderRepo) Upsert(ctx context.Context, stats *models.TokenHolderStats) error {
+	r.stored[stats.TokenID] = stats
+	return nil
+}
+
+// fakeExplorer serves holders and supplies by contract; other contracts fail with err
+type fakeExplorer struct {
+	holders  map[string][]external.TokenHolder
+	supplies map[string]string
+	err      error
+}
+
+func (e *fakeExplorer) GetTopHolders(ctx context.Context, chainID int, contract string, limit int) ([]external.TokenHolder, error) {
+	holders, ok := e.holders[contract]
+	if !ok {
+		return nil, e.err
+	}
+	return holders, nil
+}
+
+func (e *fakeExplorer) GetTokenSupply(ctx context.Context, chainID int, contract string) (string, error) {
+	supply, ok := e.supplies[contract]
+	if !ok {
+		return "", e.err
+	}
+	return supply, nil
+}
+
+func TestTokenHolderJobWorksOutShares(t *testing.T) {
+	held := &models.Token{ID: uuid.New(), Address: "0xheld", ChainID: 1, Symbol: "PEPE"}
+	unlisted := &models.Token{ID: uuid.New(), Address: "0xunlisted", ChainID: 10, Symbol: "NEW"}
+	repo := &memoryTokenHolderRepo{stale: []*models.Token{held, unlisted}, stored: map[uuid.UUID]*models.TokenHolderStats{}}
+	explorer := &fakeExplorer{
+		holders: map[string][]external.TokenHolder{"0xheld": {
+			{Address: "0xaaa", Balance: "600"},
+			{Address: "0xbbb", Balance: "150"},
+		}},
+		supplies: map[string]string{"0xheld": "1000"},
+		err:      external.ErrEtherscanNoData,
+	}
+
+	require.NoError(t, NewTokenHolderJob(repo, explorer).Run(context.Background()))
+
+	stats := repo.stored[held.ID]
+	require.NotNil(t, stats)
+	assert.Equal(t, "1000", stats.TotalSupply)
+	assert.Equal(t, 75.0, stats.Top10SharePct)
+	assert.Equal(t, []models.TokenHolderShare{
+		{Address: "0xaaa", Balance: "600", SharePct: 60},
+		{Address: "0xbbb", Balance: "150", SharePct: 15},
+	}, stats.TopHolders)
+	assert.False(t, stats.NotFound)
+
+	// Tokens the explorer has nothing on are stored so they aren't fetched every run
+	require.NotNil(t, repo.stored[unlisted.ID])
+	assert.True(t, repo.stored[unlisted.ID].NotFound)
+}
+
+func TestTokenHolderJobRetriesFailedTokens(t *testing.T) {
+	token := &models.Token{ID: uuid.New(), Address: "0xtoken", ChainID: 1, Symbol: "TKN"}
+	repo := &memoryTokenHolderRepo{stale: []*models.Token{token}, stored: map[uuid.UUID]*models.TokenHolderStats{}}
+
+	require.NoError(t, NewTokenHolderJob(repo, &fakeExplorer{err: errors.New("Etherscan API error: 502")}).Run(context.Background()))
+	assert.Empty(t, repo.stored, "a failed fetch is retried next run")
+}
@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// maxListedDecimals is the most decimals a listed token can have; balances are stored
+// in NUMERIC(78, 0)
+const maxListedDecimals = 77
+
+var listedAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// TokenListSyncJob seeds and refreshes the tokens table from the standard token lists of
+// each chain CoinGecko has an asset platform for. Token metadata is read through the
+// table before any provider is asked, so listed tokens get their symbol and decimals
+// without a provider call, and balances of tokens the providers can't describe aren't
+// skipped.
+type TokenListSyncJob struct {
+	tokenListRepo repos.TokenListRepository
+	client        *external.TokenListClient
+}
+
+func NewTokenListSyncJob(tokenListRepo repos.TokenListRepository, client *external.TokenListClient) *TokenListSyncJob {
+	return &TokenListSyncJob{
+		tokenListRepo: tokenListRepo,
+		client:        client,
+	}
+}
+
+// Run syncs every chain's listed tokens. A list that can't be fetched is skipped; the
+// run only fails when none could be.
+func (j *TokenListSyncJob) Run(ctx context.Context) error {
+	chainIDs := make([]int, 0, len(external.AssetPlatforms))
+	for chainID := range external.AssetPlatforms {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Ints(chainIDs)
+
+	// Uniswap's list covers every chain, so lists are fetched once per run
+	fetched := make(map[string]*external.TokenList)
+	var lastErr error
+	var tokens []*models.Token
+	conflicts := 0
+	for _, chainID := range chainIDs {
+		var lists []*external.TokenList
+		for _, source := range j.client.Sources(chainID) {
+			list, ok := fetched[source.URL]
+			if !ok {
+				var err error
+				if list, err = j.client.Fetch(ctx, source); err != nil {
+					logger.Warn("Failed to fetch token list", "list", source.Name, "chainId", chainID, "error", err)
+					lastErr = err
+				}
+				fetched[source.URL] = list
+			}
+			if list != nil {
+				lists = append(lists, list)
+			}
+		}
+		listed, conflicting := mergeTokenLists(chainID, lists)
+		tokens = append(tokens, listed...)
+		conflicts += conflicting
+	}
+	if len(tokens) == 0 {
+		return lastErr
+	}
+
+	result, err := j.tokenListRepo.SyncTokens(ctx, tokens)
+	if err != nil {
+		return err
+	}
+	// This process's cache may hold the gaps just filled
+	for chainID, addresses := range result.Changed {
+		blockchain.InvalidateTokenMetadata(chainID, addresses...)
+	}
+
+	logger.Info("Synced token lists", "listed", len(tokens), "inserted", result.Inserted, "filled", result.Filled, "conflicts", conflicts)
+	return nil
+}
+
+// mergeTokenLists returns the chain's tokens from lists ordered most trusted first, one
+// per address. A token's symbol and name come from the first list with it, its logo from
+// the first with one. Tokens whose lists disagree on decimals are left out, counted as
+// conflicts, as wrong decimals would misstate balances by orders of magnitude; their
+// metadata is read from a provider or on-chain as before. Entries without a valid
+// address, a symbol or sensible decimals are dropped.
+func mergeTokenLists(chainID int, lists []*external.TokenList) ([]*models.Token, int) {
+	byAddress := make(map[string]*models.Token)
+	var order []string
+	conflicting := make(map[string]bool)
+	for _, list := range lists {
+		for _, entry := range list.Tokens {
+			symbol := strings.TrimSpace(entry.Symbol)
+			if entry.ChainID != chainID || !listedAddressPattern.MatchString(entry.Address) || symbol == "" ||
+				entry.Decimals < 0 || entry.Decimals > maxListedDecimals {
+				continue
+			}
+			address := strings.ToLower(entry.Address)
+			token, seen := byAddress[address]
+			if !seen {
+				token = &models.Token{
+					Address:  address,
+					ChainID:  chainID,
+					Symbol:   symbol,
+					Name:     strings.TrimSpace(entry.Name),
+					Decimals: entry.Decimals,
+				}
+				if token.Name == "" {
+					token.Name = symbol
+				}
+				byAddress[address] = token
+				order = append(order, address)
+			} else if token.Decimals != entry.Decimals {
+				conflicting[address] = true
+			}
+			if token.LogoURI == nil && strings.HasPrefix(entry.LogoURI, "https://") {
+				logo := entry.LogoURI
+				token.LogoURI = &logo
+			}
+		}
+	}
+
+	tokens := make([]*models.Token, 0, len(order))
+	for _, address := range order {
+		if !conflicting[address] {
+			tokens = append(tokens, byAddress[address])
+		}
+	}
+	return tokens, len(conflicting)
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTokenLists(t *testing.T) {
+	uniswap := &external.TokenList{Tokens: []external.TokenListEntry{
+		{ChainID: 1, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC", Name: "USDCoin", Decimals: 6, LogoURI: "ipfs://QmXfzKRvjZz3u5JRgC4v5mGVbm9ahrUiB4DgzHBsnWbTMM"},
+		{ChainID: 1, Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F", Symbol: "DAI", Name: "Dai Stablecoin", Decimals: 18},
+		{ChainID: 137, Address: "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359", Symbol: "USDC", Decimals: 6},
+		{ChainID: 1, Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Symbol: "USDT", Name: "Tether USD", Decimals: 6},
+		{ChainID: 1, Address: "not-an-address", Symbol: "BAD", Decimals: 18},
+		{ChainID: 1, Address: "0x1111111111111111111111111111111111111111", Symbol: " ", Decimals: 18},
+		{ChainID: 1, Address: "0x2222222222222222222222222222222222222222", Symbol: "HUGE", Decimals: 255},
+	}}
+	coingecko := &external.TokenList{Tokens: []external.TokenListEntry{
+		{ChainID: 1, Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Symbol: "usdc", Name: "USD Coin", Decimals: 6, LogoURI: "https://assets.coingecko.com/coins/images/6319/thumb/usdc.png"},
+		{ChainID: 1, Address: "0xdac17f958d2ee523a2206206994597c13d831ec7", Symbol: "USDT", Name: "Tether", Decimals: 18},
+		{ChainID: 1, Address: "0x7Fc66500c84A76Ad7e9c93437bFc5Ac33E2DDaE9", Symbol: "AAVE", Decimals: 18},
+	}}
+
+	tokens, conflicts := mergeTokenLists(1, []*external.TokenList{uniswap, coingecko})
+	assert.Equal(t, 1, conflicts, "USDT's lists disagree on its decimals")
+	require.Len(t, tokens, 3)
+
+	usdc := tokens[0]
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", usdc.Address)
+	assert.Equal(t, "USDC", usdc.Symbol, "the first list's symbol wins")
+	assert.Equal(t, "USDCoin", usdc.Name)
+	require.NotNil(t, usdc.LogoURI)
+	assert.Equal(t, "https://assets.coingecko.com/coins/images/6319/thumb/usdc.png", *usdc.LogoURI, "only HTTPS logos are taken")
+
+	assert.Equal(t, "DAI", tokens[1].Symbol)
+	assert.Nil(t, tokens[1].LogoURI)
+	assert.Equal(t, "AAVE", tokens[2].Symbol)
+	assert.Equal(t, "AAVE", tokens[2].Name, "tokens without a name are named by their symbol")
+	assert.Equal(t, 1, tokens[2].ChainID)
+
+	tokens, _ = mergeTokenLists(137, []*external.TokenList{uniswap, coingecko})
+	require.Len(t, tokens, 1)
+	assert.Equal(t, 137, tokens[0].ChainID)
+}
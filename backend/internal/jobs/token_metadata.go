@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// tokenMetadataBatchSize stays under CoinGecko's free tier rate limit per run
+	tokenMetadataBatchSize = 40
+	// tokenMetadataMaxAge is how long enrichment is trusted before it is refreshed
+	tokenMetadataMaxAge = 7 * 24 * time.Hour
+)
+
+// TokenMetadataJob enriches tokens with CoinGecko categories, links and supply
+type TokenMetadataJob struct {
+	metadataRepo    repos.TokenMetadataRepository
+	coinGeckoClient *external.CoinGeckoClient
+}
+
+func NewTokenMetadataJob(metadataRepo repos.TokenMetadataRepository, cgClient *external.CoinGeckoClient) *TokenMetadataJob {
+	return &TokenMetadataJob{
+		metadataRepo:    metadataRepo,
+		coinGeckoClient: cgClient,
+	}
+}
+
+// Run enriches a batch of tokens that have no metadata or stale metadata. When the
+// CoinGecko quota runs out the rest are left for the next run.
+func (j *TokenMetadataJob) Run(ctx context.Context) error {
+	tokens, err := j.metadataRepo.GetStaleTokens(ctx, tokenMetadataMaxAge, tokenMetadataBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	enriched, missing := 0, 0
+	for i, token := range tokens {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info, err := j.fetchCoinInfo(ctx, token)
+		if QuotaExhausted(ctx, err, fmt.Sprintf("enriching %d tokens", len(tokens)-i)) {
+			break
+		}
+		if err != nil && !errors.Is(err, external.ErrCoinNotFound) {
+			logger.Warn("Failed to fetch token metadata", "token", token.Symbol, "chainID", token.ChainID, "error", err)
+			continue
+		}
+
+		metadata := &models.TokenMetadata{TokenID: token.ID, NotFound: info == nil}
+		if info != nil {
+			applyCoinInfo(metadata, info)
+			enriched++
+		} else {
+			missing++
+		}
+
+		if err := j.metadataRepo.Upsert(ctx, metadata); err != nil {
+			logger.Error("Failed to store token metadata", "token", token.Symbol, "error", err)
+		}
+	}
+
+	logger.Info("Token metadata enrichment completed", "tokens", len(tokens), "enriched", enriched, "notListed", missing)
+	return nil
+}
+
+// fetchCoinInfo looks a token up by contract, falling back to the symbol mapping for
+// native tokens and chains CoinGecko has no asset platform for
+func (j *TokenMetadataJob) fetchCoinInfo(ctx context.Context, token *models.Token) (*external.CoinInfo, error) {
+	_, hasPlatform := external.AssetPlatforms[token.ChainID]
+	if hasPlatform && !token.IsNative() {
+		info, err := j.coinGeckoClient.GetCoinInfoByContract(ctx, token.ChainID, token.Address)
+		if err == nil || !errors.Is(err, external.ErrCoinNotFound) {
+			return info, err
+		}
+	}
+
+	if coinID, ok := external.TokenIDMappings[strings.ToLower(token.Symbol)]; ok {
+		return j.coinGeckoClient.GetCoinInfo(ctx, coinID)
+	}
+	return nil, external.ErrCoinNotFound
+}
+
+// applyCoinInfo copies CoinGecko data onto metadata, leaving empty fields nil
+func applyCoinInfo(metadata *models.TokenMetadata, info *external.CoinInfo) {
+	metadata.CoinGeckoID = optionalString(info.ID)
+	metadata.Categories = make([]string, 0, len(info.Categories))
+	for _, category := range info.Categories {
+		if category = strings.TrimSpace(category); category != "" {
+			metadata.Categories = append(metadata.Categories, category)
+		}
+	}
+	metadata.Description = optionalString(info.Description.EN)
+	metadata.Website = optionalString(info.Website())
+	metadata.Twitter = optionalString(info.Links.TwitterScreenName)
+	metadata.Telegram = optionalString(info.Links.TelegramChannelID)
+	metadata.Discord = optionalString(info.Discord())
+	metadata.GitHub = optionalString(info.GitHub())
+	metadata.ImageURL = optionalString(info.Image.Large)
+	if info.MarketData != nil {
+		metadata.CirculatingSupply = info.MarketData.CirculatingSupply
+	}
+}
+
+func optionalString(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
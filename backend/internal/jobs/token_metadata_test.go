@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCoinInfo(t *testing.T) {
+	var info external.CoinInfo
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "uniswap",
+		"categories": ["Decentralized Exchange (DEX)", " ", "Governance"],
+		"description": {"en": ""},
+		"links": {
+			"homepage": ["", "https://uniswap.org/"],
+			"twitter_screen_name": "Uniswap",
+			"telegram_channel_identifier": "",
+			"chat_url": ["https://t.me/uniswap", "https://discord.com/invite/uniswap"],
+			"repos_url": {"github": ["https://github.com/Uniswap"]}
+		},
+		"image": {"large": "https://assets.coingecko.com/coins/images/12504/large/uni.jpg"},
+		"market_data": {"circulating_supply": 600000000}
+	}`), &info))
+
+	metadata := &models.TokenMetadata{}
+	applyCoinInfo(metadata, &info)
+
+	assert.Equal(t, "uniswap", *metadata.CoinGeckoID)
+	assert.Equal(t, []string{"Decentralized Exchange (DEX)", "Governance"}, metadata.Categories)
+	assert.Nil(t, metadata.Description)
+	assert.Equal(t, "https://uniswap.org/", *metadata.Website)
+	assert.Equal(t, "Uniswap", *metadata.Twitter)
+	assert.Nil(t, metadata.Telegram)
+	assert.Equal(t, "https://discord.com/invite/uniswap", *metadata.Discord)
+	assert.Equal(t, "https://github.com/Uniswap", *metadata.GitHub)
+	assert.Equal(t, 600000000.0, *metadata.CirculatingSupply)
+	assert.Equal(t, "Decentralized Exchange (DEX)", metadata.Sector())
+}
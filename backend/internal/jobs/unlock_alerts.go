@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// evaluateUnlockReminders reminds owners of reward locks whose cliff or final unlock is
+// within the alert's days. Each unlock is reminded of once: the alert fires for the locks
+// whose reminder came due since it last fired.
+func (j *AlertEvaluatorJob) evaluateUnlockReminders(ctx context.Context, alerts []models.Alert, trigger services.AlertTrigger) (int, error) {
+	now := j.clock.Now()
+	locksByUser := make(map[uuid.UUID][]*models.RewardLock)
+
+	triggered := 0
+	for _, alert := range alerts {
+		locks, ok := locksByUser[alert.UserID]
+		if !ok {
+			var err error
+			if locks, err = j.rewardLockRepo.GetByUser(ctx, alert.UserID); err != nil {
+				logger.Warn("Failed to get reward locks", "alertId", alert.ID, "error", err)
+				continue
+			}
+			locksByUser[alert.UserID] = locks
+		}
+
+		triggeredValue := unlockReminderTrigger(alert, locks, now)
+		if triggeredValue == nil {
+			continue
+		}
+
+		if err := trigger(ctx, &alert, triggeredValue); err != nil {
+			logger.Error("Failed to trigger alert",
+				"alertId", alert.ID,
+				"error", err)
+			continue
+		}
+		triggered++
+	}
+
+	return triggered, nil
+}
+
+// unlockReminderTrigger lists the alert's locks whose reminder came due since the alert
+// last fired, or returns nil when there are none
+func unlockReminderTrigger(alert models.Alert, locks []*models.RewardLock, now time.Time) map[string]interface{} {
+	if alert.Conditions.DaysBefore == nil {
+		return nil
+	}
+	lead := time.Duration(*alert.Conditions.DaysBefore) * 24 * time.Hour
+
+	var unlocks []map[string]interface{}
+	for _, lock := range locks {
+		if alert.Target.Type == models.AlertTargetTypeRewardLock && lock.ID.String() != alert.Target.Identifier {
+			continue
+		}
+		next := lock.NextUnlock(now)
+		if next == nil {
+			continue
+		}
+		due := next.Add(-lead)
+		if due.After(now) || (alert.LastTriggeredAt != nil && !due.After(*alert.LastTriggeredAt)) {
+			continue
+		}
+
+		unlocks = append(unlocks, map[string]interface{}{
+			"lockId":      lock.ID,
+			"protocol":    lock.Protocol,
+			"kind":        lock.Kind,
+			"tokenSymbol": lock.TokenSymbol,
+			"amount":      lock.UnlockedAt(*next) - lock.UnlockedAt(now),
+			"unlocksAt":   *next,
+		})
+	}
+	if len(unlocks) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"daysBefore": *alert.Conditions.DaysBefore,
+		"unlocks":    unlocks,
+	}
+}
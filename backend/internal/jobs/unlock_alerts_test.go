@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlockReminderTrigger(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	days := 7
+	soon := &models.RewardLock{ID: uuid.New(), Kind: models.RewardLockKindVoteEscrow, TokenSymbol: "CRV", Amount: 100, UnlocksAt: now.AddDate(0, 0, 5)}
+	later := &models.RewardLock{ID: uuid.New(), Kind: models.RewardLockKindVoteEscrow, TokenSymbol: "BAL", Amount: 10, UnlocksAt: now.AddDate(0, 0, 30)}
+	locks := []*models.RewardLock{soon, later}
+	alert := models.Alert{Target: models.AlertTarget{Type: models.AlertTargetTypePortfolio}, Conditions: models.AlertConditions{DaysBefore: &days}}
+
+	value := unlockReminderTrigger(alert, locks, now)
+	require.NotNil(t, value)
+	unlocks := value["unlocks"].([]map[string]interface{})
+	require.Len(t, unlocks, 1)
+	assert.Equal(t, soon.ID, unlocks[0]["lockId"])
+	assert.Equal(t, 100.0, unlocks[0]["amount"])
+
+	// Once reminded, the same unlock doesn't fire again
+	remindedAt := now.Add(-time.Hour)
+	alert.LastTriggeredAt = &remindedAt
+	assert.Nil(t, unlockReminderTrigger(alert, locks, now))
+
+	// Alerts on one lock ignore the others
+	alert.LastTriggeredAt = nil
+	alert.Target = models.AlertTarget{Type: models.AlertTargetTypeRewardLock, Identifier: later.ID.String()}
+	assert.Nil(t, unlockReminderTrigger(alert, locks, now))
+}
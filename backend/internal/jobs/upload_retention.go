@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// UploadRetentionJob deletes stored exports, reports and imports past their retention
+type UploadRetentionJob struct {
+	uploadService *services.UploadService
+}
+
+func NewUploadRetentionJob(uploadService *services.UploadService) *UploadRetentionJob {
+	return &UploadRetentionJob{uploadService: uploadService}
+}
+
+func (j *UploadRetentionJob) Run(ctx context.Context) error {
+	purged, err := j.uploadService.PurgeExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to purge expired uploads: %w", err)
+	}
+
+	if purged > 0 {
+		logger.Info("Upload retention cleanup completed", "purged", purged)
+	}
+	return nil
+}
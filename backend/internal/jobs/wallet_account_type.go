@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// walletAccountTypeBatchSize caps the wallets detected per run
+const walletAccountTypeBatchSize = 100
+
+// WalletAccountTypeJob detects whether newly added EVM wallets are EOAs, Safes, ERC-4337
+// accounts or other contracts. Wallets it can't read yet are retried on the next run.
+type WalletAccountTypeJob struct {
+	walletRepo        repos.WalletRepository
+	blockchainService *blockchain.BlockchainService
+}
+
+func NewWalletAccountTypeJob(walletRepo repos.WalletRepository, blockchainService *blockchain.BlockchainService) *WalletAccountTypeJob {
+	return &WalletAccountTypeJob{
+		walletRepo:        walletRepo,
+		blockchainService: blockchainService,
+	}
+}
+
+func (j *WalletAccountTypeJob) Run(ctx context.Context) error {
+	wallets, err := j.walletRepo.GetUndetectedAccounts(ctx, walletAccountTypeBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(wallets) == 0 {
+		return nil
+	}
+
+	detected := 0
+	for _, wallet := range wallets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		accountType, err := j.blockchainService.DetectAccountType(ctx, wallet.ChainID, wallet.Address)
+		if err != nil {
+			logger.Warn("Failed to detect wallet account type", "walletId", wallet.ID, "chainId", wallet.ChainID, "error", err)
+			continue
+		}
+		if err := j.walletRepo.SetAccountType(ctx, wallet.ID, accountType); err != nil {
+			logger.Error("Failed to save wallet account type", "walletId", wallet.ID, "error", err)
+			continue
+		}
+		detected++
+	}
+
+	logger.Info("Wallet account type detection finished", "detected", detected, "pending", len(wallets)-detected)
+	return nil
+}
@@ -0,0 +1,247 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	walletBackfillBatchSize = 20
+	// A backfill's blocks are split into about this many chunks, so progress moves by
+	// roughly a percent per chunk whatever the chain's height
+	walletBackfillChunks       = 100
+	minWalletBackfillChunkSize = 10000
+	// maxWalletBackfillAttempts is how many chunks in a row may fail before the backfill does
+	maxWalletBackfillAttempts = 5
+	// walletBackfillRunBudget ends a run before the next one is due, so wallets added
+	// meanwhile start importing within a minute
+	walletBackfillRunBudget = 50 * time.Second
+)
+
+// WalletBackfillJob imports the full transaction history of wallets, queuing a backfill
+// for each EVM wallet the first time it sees it. Backfills run a chunk of blocks at a
+// time, taking turns so a new wallet isn't stuck behind a long history, and persist
+// their progress after every chunk so the next run resumes where this one stopped.
+type WalletBackfillJob struct {
+	backfillRepo      repos.WalletBackfillRepository
+	blockchainService *blockchain.BlockchainService
+	// onboarding hears of completed backfills, a wallet's first sync; may be nil
+	onboarding *services.OnboardingService
+	// analytics hears of newly seen wallets; may be nil
+	analytics services.EventTracker
+	clock     clock.Clock
+}
+
+func NewWalletBackfillJob(backfillRepo repos.WalletBackfillRepository, blockchainService *blockchain.BlockchainService, onboarding *services.OnboardingService) *WalletBackfillJob {
+	return &WalletBackfillJob{
+		backfillRepo:      backfillRepo,
+		blockchainService: blockchainService,
+		onboarding:        onboarding,
+		clock:             clock.System,
+	}
+}
+
+// SetAnalytics reports newly queued wallets as wallet_added usage events
+func (j *WalletBackfillJob) SetAnalytics(analytics services.EventTracker) {
+	j.analytics = analytics
+}
+
+// Run queues backfills for new wallets and advances the active ones until they complete
+// or the run's budget is spent
+func (j *WalletBackfillJob) Run(ctx context.Context) error {
+	queued, err := j.enqueueMissing(ctx)
+	if err != nil {
+		return err
+	}
+	active, err := j.backfillRepo.GetActive(ctx, walletBackfillBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	logger.Info("Starting wallet backfill job", "queued", queued, "active", len(active))
+
+	deadline := j.clock.Now().Add(walletBackfillRunBudget)
+	completed := 0
+	for len(active) > 0 && j.clock.Now().Before(deadline) {
+		var next []*models.WalletBackfill
+		for _, backfill := range active {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := j.step(ctx, backfill); err != nil {
+				logger.Warn("Wallet backfill chunk failed", "walletId", backfill.WalletID, "chainId", backfill.ChainID, "nextBlock", backfill.NextBlock, "error", err)
+				if err := j.backfillRepo.RecordError(ctx, backfill.ID, err.Error(), maxWalletBackfillAttempts); err != nil {
+					logger.Error("Failed to record wallet backfill error", "walletId", backfill.WalletID, "error", err)
+				}
+				continue
+			}
+			if backfill.Status == models.BackfillStatusCompleted {
+				completed++
+				logger.Info("Wallet backfill completed", "walletId", backfill.WalletID, "chainId", backfill.ChainID, "transactions", backfill.TransactionsFound)
+				j.completeFirstSync(ctx, backfill.WalletID)
+				continue
+			}
+			next = append(next, backfill)
+		}
+		active = next
+	}
+
+	logger.Info("Wallet backfill job finished", "completed", completed, "remaining", len(active))
+	return nil
+}
+
+// BackfillWallet runs one wallet's backfill to completion, queuing it first if the
+// wallet is new, without the run budget scheduled runs keep to. A failed chunk is
+// recorded like in scheduled runs and ends the backfill here.
+func (j *WalletBackfillJob) BackfillWallet(ctx context.Context, walletID uuid.UUID) error {
+	backfill, err := j.backfillRepo.GetByWalletID(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if backfill == nil {
+		if _, err := j.enqueueMissing(ctx); err != nil {
+			return err
+		}
+		if backfill, err = j.backfillRepo.GetByWalletID(ctx, walletID); err != nil {
+			return err
+		}
+		if backfill == nil {
+			return fmt.Errorf("wallet %s can't be backfilled", walletID)
+		}
+	}
+
+	for backfill.Status != models.BackfillStatusCompleted && backfill.Status != models.BackfillStatusFailed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := j.step(ctx, backfill); err != nil {
+			if recordErr := j.backfillRepo.RecordError(ctx, backfill.ID, err.Error(), maxWalletBackfillAttempts); recordErr != nil {
+				logger.Error("Failed to record wallet backfill error", "walletId", backfill.WalletID, "error", recordErr)
+			}
+			return fmt.Errorf("wallet backfill chunk from block %d failed: %w", backfill.NextBlock, err)
+		}
+		logger.Info("Wallet backfill chunk imported", "walletId", backfill.WalletID, "nextBlock", backfill.NextBlock, "transactions", backfill.TransactionsFound)
+	}
+
+	if backfill.Status == models.BackfillStatusFailed {
+		message := "unknown"
+		if backfill.Error != nil {
+			message = *backfill.Error
+		}
+		return fmt.Errorf("wallet backfill has failed, last error: %s", message)
+	}
+	logger.Info("Wallet backfill completed", "walletId", backfill.WalletID, "chainId", backfill.ChainID, "transactions", backfill.TransactionsFound)
+	j.completeFirstSync(ctx, backfill.WalletID)
+	return nil
+}
+
+func (j *WalletBackfillJob) completeFirstSync(ctx context.Context, walletID uuid.UUID) {
+	if j.onboarding != nil {
+		j.onboarding.CompleteWalletStep(ctx, walletID, models.OnboardingStepFirstSync)
+	}
+}
+
+// enqueueMissing queues backfills for new wallets, returning how many were queued. A
+// wallet queued is one seen for the first time, so it's reported as added.
+func (j *WalletBackfillJob) enqueueMissing(ctx context.Context) (int, error) {
+	queued, err := j.backfillRepo.EnqueueMissing(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if j.analytics != nil {
+		for _, backfill := range queued {
+			j.analytics.Track(backfill.UserID, models.AnalyticsWalletAdded, map[string]interface{}{"chain": "evm", "chain_id": backfill.ChainID})
+		}
+	}
+	return len(queued), nil
+}
+
+// step imports the backfill's next chunk of blocks, starting it at the chain head first.
+// A backfill whose address another user already imported shares that history instead.
+func (j *WalletBackfillJob) step(ctx context.Context, backfill *models.WalletBackfill) error {
+	if backfill.TargetBlock == nil {
+		copied, err := j.backfillRepo.CopyFromPeer(ctx, backfill)
+		if err != nil {
+			return err
+		}
+		if copied {
+			return nil
+		}
+		head, err := j.blockchainService.GetLatestBlockNumber(ctx, backfill.ChainID)
+		if err != nil {
+			return err
+		}
+		if err := j.backfillRepo.Start(ctx, backfill, head); err != nil {
+			return err
+		}
+	}
+
+	blocks := nextBackfillRange(backfill.NextBlock, *backfill.TargetBlock)
+	transactions, err := j.blockchainService.GetTransactionsInRange(ctx, backfill.WalletAddress, backfill.ChainID, blocks)
+	if err != nil {
+		return err
+	}
+	for _, tx := range transactions {
+		normalizeBackfilledTransaction(tx, backfill.WalletAddress)
+	}
+	// Fees that can't be priced now are left to the gas fee backfill
+	j.blockchainService.EnrichGasFees(ctx, transactions)
+	return j.backfillRepo.SaveChunk(ctx, backfill, transactions, blocks.To+1)
+}
+
+// nextBackfillRange returns the chunk of blocks starting at from, ending no later than
+// the target block
+func nextBackfillRange(from, target int64) blockchain.BlockRange {
+	size := (target + 1) / walletBackfillChunks
+	if size < minWalletBackfillChunkSize {
+		size = minWalletBackfillChunkSize
+	}
+	to := from + size - 1
+	if to > target {
+		to = target
+	}
+	return blockchain.BlockRange{From: from, To: to}
+}
+
+// normalizeBackfilledTransaction maps a provider transaction onto the values the
+// transactions table stores: a send or receive from the wallet's side, a settled status
+// and a decimal value. The provider's own category is kept in the metadata.
+func normalizeBackfilledTransaction(tx *models.Transaction, walletAddress string) {
+	if tx.Metadata == nil {
+		tx.Metadata = make(map[string]interface{})
+	}
+	if tx.Type != "" {
+		tx.Metadata["category"] = tx.Type
+	}
+	tx.Type = "receive"
+	if strings.EqualFold(tx.FromAddress, walletAddress) {
+		tx.Type = "send"
+	}
+
+	if tx.Status != models.TransactionStatusFailed {
+		tx.Status = models.TransactionStatusConfirmed
+	}
+
+	if tx.Value != nil && strings.HasPrefix(*tx.Value, "0x") {
+		if v, ok := new(big.Int).SetString((*tx.Value)[2:], 16); ok {
+			value := v.String()
+			tx.Value = &value
+		} else {
+			tx.Value = nil
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextBackfillRange(t *testing.T) {
+	// A hundredth of the chain per chunk
+	assert.Equal(t, blockchain.BlockRange{From: 0, To: 199999}, nextBackfillRange(0, 19999999))
+	assert.Equal(t, blockchain.BlockRange{From: 200000, To: 399999}, nextBackfillRange(200000, 19999999))
+	// but no less than the minimum chunk, and never past the target
+	assert.Equal(t, blockchain.BlockRange{From: 0, To: 9999}, nextBackfillRange(0, 50000))
+	assert.Equal(t, blockchain.BlockRange{From: 45000, To: 50000}, nextBackfillRange(45000, 50000))
+}
+
+func TestNormalizeBackfilledTransaction(t *testing.T) {
+	wallet := "0xAbC0000000000000000000000000000000000001"
+	value := "0x12a05f200"
+	sent := &models.Transaction{FromAddress: "0xabc0000000000000000000000000000000000001", Type: "erc20", Status: "success", Value: &value}
+	normalizeBackfilledTransaction(sent, wallet)
+	assert.Equal(t, "send", sent.Type)
+	assert.Equal(t, models.TransactionStatusConfirmed, sent.Status)
+	assert.Equal(t, "5000000000", *sent.Value)
+	assert.Equal(t, "erc20", sent.Metadata["category"])
+
+	received := &models.Transaction{FromAddress: "0x9999999999999999999999999999999999999999", Type: "external", Status: models.TransactionStatusFailed}
+	normalizeBackfilledTransaction(received, wallet)
+	assert.Equal(t, "receive", received.Type)
+	assert.Equal(t, models.TransactionStatusFailed, received.Status)
+	assert.Nil(t, received.Value)
+}
+
+// peerBackfills queues backfills for the wallets in missing and completes each from a
+// peer's, so no chunk is fetched from the chain
+type peerBackfills struct {
+	repos.WalletBackfillRepository
+	backfills map[uuid.UUID]*models.WalletBackfill
+	missing   []uuid.UUID
+	copyErr   error
+	errors    []string
+}
+
+func (r *peerBackfills) EnqueueMissing(context.Context) ([]*models.WalletBackfill, error) {
+	var queued []*models.WalletBackfill
+	for _, walletID := range r.missing {
+		backfill := &models.WalletBackfill{ID: uuid.New(), WalletID: walletID, ChainID: 1, Status: models.BackfillStatusPending}
+		r.backfills[walletID] = backfill
+		queued = append(queued, backfill)
+	}
+	r.missing = nil
+	return queued, nil
+}
+
+func (r *peerBackfills) GetByWalletID(_ context.Context, walletID uuid.UUID) (*models.WalletBackfill, error) {
+	return r.backfills[walletID], nil
+}
+
+func (r *peerBackfills) CopyFromPeer(_ context.Context, backfill *models.WalletBackfill) (bool, error) {
+	if r.copyErr != nil {
+		return false, r.copyErr
+	}
+	backfill.Status, backfill.TransactionsFound = models.BackfillStatusCompleted, 12
+	return true, nil
+}
+
+func (r *peerBackfills) RecordError(_ context.Context, _ uuid.UUID, message string, _ int) error {
+	r.errors = append(r.errors, message)
+	return nil
+}
+
+func TestWalletBackfillJobBackfillWallet(t *testing.T) {
+	ctx := context.Background()
+	added, failed, unknown := uuid.New(), uuid.New(), uuid.New()
+	lastError := "rpc unavailable"
+	repo := &peerBackfills{
+		backfills: map[uuid.UUID]*models.WalletBackfill{
+			failed: {ID: uuid.New(), WalletID: failed, Status: models.BackfillStatusFailed, Error: &lastError},
+		},
+		missing: []uuid.UUID{added},
+	}
+	job := NewWalletBackfillJob(repo, nil, nil)
+
+	// A wallet added since the last scheduled run is queued and backfilled
+	require.NoError(t, job.BackfillWallet(ctx, added))
+	assert.Equal(t, models.BackfillStatusCompleted, repo.backfills[added].Status)
+
+	assert.EqualError(t, job.BackfillWallet(ctx, failed), "wallet backfill has failed, last error: rpc unavailable")
+	assert.EqualError(t, job.BackfillWallet(ctx, unknown), fmt.Sprintf("wallet %s can't be backfilled", unknown))
+
+	// A failed chunk counts towards failing the backfill, as in scheduled runs
+	repo.copyErr = fmt.Errorf("connection reset")
+	repo.backfills[unknown] = &models.WalletBackfill{ID: uuid.New(), WalletID: unknown, Status: models.BackfillStatusPending}
+	assert.ErrorContains(t, job.BackfillWallet(ctx, unknown), "connection reset")
+	assert.Equal(t, []string{"connection reset"}, repo.errors)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, job.BackfillWallet(cancelled, unknown), context.Canceled)
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// walletRemovalMaxAttempts is how many runs in a row a step may fail before its
+	// removal is given up on, so one bad removal doesn't hold up those queued after it
+	walletRemovalMaxAttempts = 5
+	// walletRemovalRunBudget ends a run before the next one is due
+	walletRemovalRunBudget = 50 * time.Second
+)
+
+// WalletRemovalJob works through the wallets users removed, oldest first, one step at
+// a time. Progress is saved after every step so the next run resumes where this one
+// stopped; a step that fails is retried next run.
+type WalletRemovalJob struct {
+	removalRepo repos.WalletRemovalRepository
+	clock       clock.Clock
+}
+
+func NewWalletRemovalJob(removalRepo repos.WalletRemovalRepository) *WalletRemovalJob {
+	return &WalletRemovalJob{
+		removalRepo: removalRepo,
+		clock:       clock.System,
+	}
+}
+
+// Run advances the active removals until none are left, a step fails or the run's
+// budget is spent
+func (j *WalletRemovalJob) Run(ctx context.Context) error {
+	deadline := j.clock.Now().Add(walletRemovalRunBudget)
+	for j.clock.Now().Before(deadline) {
+		removal, err := j.removalRepo.GetNextActive(ctx)
+		if err != nil || removal == nil {
+			return err
+		}
+		if removal.Status == models.WalletRemovalPending {
+			if err := j.removalRepo.Start(ctx, removal, j.clock.Now()); err != nil {
+				return err
+			}
+			logger.Info("Starting wallet removal", "removalId", removal.ID, "walletId", removal.WalletID, "retention", removal.Retention)
+		}
+		if err := j.remove(ctx, removal, deadline); err != nil {
+			return err
+		}
+		if removal.Status != models.WalletRemovalCompleted && removal.Status != models.WalletRemovalFailed {
+			return nil
+		}
+	}
+	return nil
+}
+
+// remove runs the removal's remaining steps in order, saving after each one
+func (j *WalletRemovalJob) remove(ctx context.Context, removal *models.WalletRemoval, deadline time.Time) error {
+	for step := removal.NextStep(); step != ""; step = removal.NextStep() {
+		if !j.clock.Now().Before(deadline) {
+			return nil
+		}
+
+		rows, stepErr := j.removalRepo.RunStep(ctx, removal, step)
+		if stepErr != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			message := stepErr.Error()
+			removal.LastError = &message
+			removal.Attempts++
+			if removal.Attempts >= walletRemovalMaxAttempts {
+				now := j.clock.Now()
+				removal.Status = models.WalletRemovalFailed
+				removal.CompletedAt = &now
+			}
+			if err := j.removalRepo.SaveProgress(ctx, removal); err != nil {
+				return err
+			}
+			logger.Warn("Wallet removal step failed", "removalId", removal.ID, "step", step, "attempts", removal.Attempts, "error", stepErr)
+			return stepErr
+		}
+
+		removal.RowsDeleted += rows
+		removal.StepsDone++
+		removal.Attempts = 0
+		if err := j.removalRepo.SaveProgress(ctx, removal); err != nil {
+			return err
+		}
+	}
+
+	now := j.clock.Now()
+	removal.Status = models.WalletRemovalCompleted
+	removal.CompletedAt = &now
+	if err := j.removalRepo.SaveProgress(ctx, removal); err != nil {
+		return err
+	}
+	logger.Info("Wallet removal completed", "removalId", removal.ID, "walletId", removal.WalletID, "rowsDeleted", removal.RowsDeleted)
+	return nil
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryWalletRemovalRepo struct {
+	repos.WalletRemovalRepository
+	removals []*models.WalletRemoval
+	ran      []string
+	// failing steps return an error every time they're run
+	failing map[string]bool
+	saves   int
+}
+
+func (r *memoryWalletRemovalRepo) GetNextActive(ctx context.Context) (*models.WalletRemoval, error) {
+	for _, removal := range r.removals {
+		if removal.Status == models.WalletRemovalPending || removal.Status == models.WalletRemovalRunning {
+			return removal, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryWalletRemovalRepo) Start(ctx context.Context, removal *models.WalletRemoval, at time.Time) error {
+	removal.Status = models.WalletRemovalRunning
+	removal.StartedAt = &at
+	return nil
+}
+
+func (r *memoryWalletRemovalRepo) RunStep(ctx context.Context, removal *models.WalletRemoval, step string) (int64, error) {
+	if r.failing[step] {
+		return 0, errors.New("deadlock detected")
+	}
+	r.ran = append(r.ran, step)
+	return 2, nil
+}
+
+func (r *memoryWalletRemovalRepo) SaveProgress(ctx context.Context, removal *models.WalletRemoval) error {
+	r.saves++
+	return nil
+}
+
+func newWalletRemoval(retention string) *models.WalletRemoval {
+	return &models.WalletRemoval{
+		ID:         uuid.New(),
+		WalletID:   uuid.New(),
+		Retention:  retention,
+		Status:     models.WalletRemovalPending,
+		StepsTotal: len(models.WalletRemovalSteps(retention)),
+	}
+}
+
+func TestWalletRemovalJobRunsSteps(t *testing.T) {
+	kept := newWalletRemoval(models.WalletRemovalKeepHistory)
+	purged := newWalletRemoval(models.WalletRemovalPurge)
+	repo := &memoryWalletRemovalRepo{removals: []*models.WalletRemoval{kept, purged}}
+	job := NewWalletRemovalJob(repo)
+
+	require.NoError(t, job.Run(context.Background()))
+
+	for _, removal := range []*models.WalletRemoval{kept, purged} {
+		assert.Equal(t, models.WalletRemovalCompleted, removal.Status, removal.Retention)
+		assert.Equal(t, removal.StepsTotal, removal.StepsDone)
+		assert.Equal(t, int64(2*removal.StepsTotal), removal.RowsDeleted)
+		require.NotNil(t, removal.CompletedAt)
+		assert.Empty(t, removal.NextStep())
+		assert.Equal(t, 100.0, removal.DonePercent())
+	}
+
+	steps := models.WalletRemovalSteps(models.WalletRemovalPurge)
+	assert.Equal(t, append(models.WalletRemovalSteps(models.WalletRemovalKeepHistory), steps...), repo.ran)
+	assert.NotContains(t, models.WalletRemovalSteps(models.WalletRemovalKeepHistory), models.WalletRemovalStepLots,
+		"keeping history keeps the PnL lots")
+	assert.Equal(t, models.WalletRemovalStepWallet, steps[len(steps)-1], "a purge deletes the wallet last")
+}
+
+func TestWalletRemovalJobRetriesFailedStep(t *testing.T) {
+	removal := newWalletRemoval(models.WalletRemovalPurge)
+	next := newWalletRemoval(models.WalletRemovalKeepHistory)
+	repo := &memoryWalletRemovalRepo{
+		removals: []*models.WalletRemoval{removal, next},
+		failing:  map[string]bool{models.WalletRemovalStepTransactions: true},
+	}
+	job := NewWalletRemovalJob(repo)
+
+	assert.Error(t, job.Run(context.Background()))
+	assert.Equal(t, models.WalletRemovalRunning, removal.Status)
+	assert.Equal(t, models.WalletRemovalStepTransactions, removal.NextStep(), "the failed step is retried next run")
+	assert.Equal(t, 1, removal.Attempts)
+	require.NotNil(t, removal.LastError)
+	assert.Less(t, removal.DonePercent(), 100.0)
+	assert.Equal(t, models.WalletRemovalPending, next.Status, "removals run in order")
+
+	for i := 1; i < walletRemovalMaxAttempts; i++ {
+		assert.Error(t, job.Run(context.Background()))
+	}
+	assert.Equal(t, models.WalletRemovalFailed, removal.Status, "a step failing every run is given up on")
+	assert.Empty(t, removal.NextStep())
+
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, models.WalletRemovalCompleted, next.Status, "removals queued after a failed one go ahead")
+}
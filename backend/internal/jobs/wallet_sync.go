@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+)
+
+// Wallet sync steps, reported as they start
+const (
+	WalletSyncStepNFTTransfers        = "nft_transfers"
+	WalletSyncStepDerivativePositions = "derivative_positions"
+)
+
+// WalletSyncJob syncs a single wallet on request, running the per-wallet parts of the
+// scheduled sync jobs so a newly added wallet doesn't wait for their next run
+type WalletSyncJob struct {
+	walletRepo  repos.WalletRepository
+	nftSync     *NFTTransferSyncJob
+	derivatives *DerivativePositionSyncJob
+}
+
+func NewWalletSyncJob(walletRepo repos.WalletRepository, nftSync *NFTTransferSyncJob, derivatives *DerivativePositionSyncJob) *WalletSyncJob {
+	return &WalletSyncJob{
+		walletRepo:  walletRepo,
+		nftSync:     nftSync,
+		derivatives: derivatives,
+	}
+}
+
+// Sync syncs the wallet, calling progress as each step starts. Only EVM wallets have
+// anything to sync here; other chains are synced by their own jobs.
+func (j *WalletSyncJob) Sync(ctx context.Context, walletID uuid.UUID, progress func(step string)) error {
+	wallet, err := j.walletRepo.GetByID(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	if !wallet.IsEVM() {
+		return nil
+	}
+
+	progress(WalletSyncStepNFTTransfers)
+	if _, err := j.nftSync.SyncWallet(ctx, wallet.ID, wallet.Address, wallet.ChainID); err != nil {
+		return fmt.Errorf("%s: %w", WalletSyncStepNFTTransfers, err)
+	}
+
+	progress(WalletSyncStepDerivativePositions)
+	if err := j.derivatives.SyncAddress(ctx, wallet.Address); err != nil {
+		return fmt.Errorf("%s: %w", WalletSyncStepDerivativePositions, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// walletValuationRetention is how long wallet valuation snapshots are kept
+const walletValuationRetention = 90 * 24 * time.Hour
+
+const (
+	// holdingSnapshotInterval is how often per-token holdings are snapshotted
+	holdingSnapshotInterval = time.Hour
+	// holdingSnapshotRetention is how long holdings snapshots are kept, and so how far
+	// back portfolio diffs go
+	holdingSnapshotRetention = 30 * 24 * time.Hour
+)
+
+// WalletValuationJob snapshots the USD value of every wallet for portfolio value alerts,
+// and every hour the per-token holdings behind it for portfolio diffs
+type WalletValuationJob struct {
+	valuationRepo repos.WalletValuationRepository
+	clock         clock.Clock
+}
+
+func NewWalletValuationJob(valuationRepo repos.WalletValuationRepository) *WalletValuationJob {
+	return &WalletValuationJob{valuationRepo: valuationRepo, clock: clock.System}
+}
+
+func (j *WalletValuationJob) Run(ctx context.Context) error {
+	recorded, err := j.valuationRepo.RecordAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to record wallet valuations: %w", err)
+	}
+
+	pruned, err := j.valuationRepo.DeleteOlderThan(ctx, j.clock.Now().Add(-walletValuationRetention))
+	if err != nil {
+		logger.Warn("Failed to prune wallet valuations", "error", err)
+	}
+
+	logger.Info("Wallet valuation snapshot completed", "wallets", recorded, "pruned", pruned)
+
+	// The run is a little early or late each time, so allow some slack
+	latest, err := j.valuationRepo.GetLatestHoldingsRun(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest holdings snapshot: %w", err)
+	}
+	if latest != nil && j.clock.Now().Sub(*latest) < holdingSnapshotInterval-time.Minute {
+		return nil
+	}
+	holdings, err := j.valuationRepo.RecordHoldings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to record wallet holdings: %w", err)
+	}
+	prunedRuns, err := j.valuationRepo.DeleteHoldingsOlderThan(ctx, j.clock.Now().Add(-holdingSnapshotRetention))
+	if err != nil {
+		logger.Warn("Failed to prune wallet holdings", "error", err)
+	}
+
+	logger.Info("Wallet holdings snapshot completed", "holdings", holdings, "pruned_runs", prunedRuns)
+	return nil
+}
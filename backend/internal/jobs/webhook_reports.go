@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// WebhookReportJob posts users' webhook reports when their schedules come due
+type WebhookReportJob struct {
+	webhookReportService *services.WebhookReportService
+}
+
+func NewWebhookReportJob(webhookReportService *services.WebhookReportService) *WebhookReportJob {
+	return &WebhookReportJob{webhookReportService: webhookReportService}
+}
+
+// Run posts the reports due now
+func (j *WebhookReportJob) Run(ctx context.Context) error {
+	delivered, failed, err := j.webhookReportService.RunDue(ctx)
+	if err != nil {
+		return err
+	}
+	if delivered+failed > 0 {
+		logger.Info("Posted webhook reports", "delivered", delivered, "failed", failed)
+	}
+	return nil
+}
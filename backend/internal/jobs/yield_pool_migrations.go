@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// YieldPoolMigrationJob tells the holders of positions in deprecated yield pools where
+// their pool migrated to
+type YieldPoolMigrationJob struct {
+	migrationService *services.YieldPoolMigrationService
+}
+
+func NewYieldPoolMigrationJob(migrationService *services.YieldPoolMigrationService) *YieldPoolMigrationJob {
+	return &YieldPoolMigrationJob{migrationService: migrationService}
+}
+
+// Run notifies the holders not told yet
+func (j *YieldPoolMigrationJob) Run(ctx context.Context) error {
+	notified, err := j.migrationService.NotifyHolders(ctx)
+	if err != nil {
+		return err
+	}
+	if notified > 0 {
+		logger.Info("Notified holders of yield pool migrations", "notified", notified)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// API versions served under /api/v<n>. v1 keeps the response shapes the deployed
+// frontend reads; v2 answers every endpoint in the standard envelope.
+const (
+	APIVersion1 = 1
+	APIVersion2 = 2
+)
+
+// APIVersion tags requests with the version of the group they came in on, stored in
+// c.Locals("apiVersion") and echoed in the API-Version header, so handlers can pick the
+// response shape. Responses the handlers mark deprecated also get a Link to the same
+// path on the next version and, when sunset is set, a Sunset header.
+func APIVersion(version int, sunset *time.Time) fiber.Handler {
+	prefix := "/api/v" + strconv.Itoa(version) + "/"
+	successor := "/api/v" + strconv.Itoa(version+1) + "/"
+
+	return func(c *fiber.Ctx) error {
+		// A request rewritten from a later version keeps that version
+		if _, ok := c.Locals("apiVersion").(int); ok {
+			return c.Next()
+		}
+		c.Locals("apiVersion", version)
+		c.Set("API-Version", strconv.Itoa(version))
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.GetRespHeader("Deprecation") == "" {
+			return nil
+		}
+		if url := c.OriginalURL(); strings.HasPrefix(url, prefix) {
+			c.Append(fiber.HeaderLink, "<"+successor+strings.TrimPrefix(url, prefix)+`>; rel="successor-version"`)
+		}
+		if sunset != nil {
+			c.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		return nil
+	}
+}
+
+// SharedRoutes serves a version from the routes of the one before it by rewriting its
+// prefix, for versions that only change response shapes. It must be registered ahead
+// of those routes.
+func SharedRoutes(version int) fiber.Handler {
+	prefix := "/api/v" + strconv.Itoa(version)
+	shared := "/api/v" + strconv.Itoa(version-1)
+
+	return func(c *fiber.Ctx) error {
+		c.Locals("apiVersion", version)
+		c.Set("API-Version", strconv.Itoa(version))
+		c.Path(shared + strings.TrimPrefix(c.Path(), prefix))
+		return c.Next()
+	}
+}
+
+// RequestAPIVersion returns the API version the request came in on, v1 outside the
+// versioned groups
+func RequestAPIVersion(c *fiber.Ctx) int {
+	if version, ok := c.Locals("apiVersion").(int); ok {
+		return version
+	}
+	return APIVersion1
+}
\ No newline at end of file
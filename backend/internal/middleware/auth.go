@@ -148,4 +148,15 @@ func AdminAuth() fiber.Handler {
 		}
 		return c.Next()
 	}
+}
+
+// TokenFromQuery copies a ?token= query parameter into the Authorization header
+// for clients that can't set headers, such as browser websockets
+func TokenFromQuery() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token := c.Query("token"); token != "" && c.Get("Authorization") == "" {
+			c.Request().Header.Set("Authorization", "Bearer "+token)
+		}
+		return c.Next()
+	}
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Compress brotli- or gzip-encodes responses of at least minSize bytes for clients that
+// accept it. Smaller bodies aren't worth the CPU, and streamed responses (server-sent
+// events, downloads) are left alone so they aren't buffered.
+func Compress(minSize int) fiber.Handler {
+	compressor := fasthttp.CompressHandlerBrotliLevel(func(*fasthttp.RequestCtx) {},
+		fasthttp.CompressBrotliDefaultCompression,
+		fasthttp.CompressDefaultCompression,
+	)
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().IsBodyStream() || len(c.Response().Body()) < minSize {
+			return nil
+		}
+		compressor(c.Context())
+		return nil
+	}
+}
+
+// BodyLimit rejects requests whose body is larger than limit bytes, for routes that
+// should accept less than the server-wide limit
+func BodyLimit(limit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Request().Header.ContentLength() > limit || len(c.Body()) > limit {
+			return errors.New("PAYLOAD_TOO_LARGE",
+				fmt.Sprintf("Request body exceeds %d bytes", limit), fiber.StatusRequestEntityTooLarge)
+		}
+		return c.Next()
+	}
+}
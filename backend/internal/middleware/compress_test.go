@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress_RespectsMinSize(t *testing.T) {
+	app := setupTestApp()
+	app.Use(Compress(1024))
+	large := `[` + strings.Repeat(`{"pool":"aave-v3-usdc","apy":4.2},`, 100) + `{}]`
+	app.Get("/pools", func(c *fiber.Ctx) error {
+		return c.SendString(large)
+	})
+	app.Get("/small", func(c *fiber.Ctx) error {
+		return c.SendString(`{"ok":true}`)
+	})
+
+	for _, encoding := range []string{"br", "gzip"} {
+		req := httptest.NewRequest("GET", "/pools", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, encoding, resp.Header.Get("Content-Encoding"))
+		body, _ := io.ReadAll(resp.Body)
+		assert.Less(t, len(body), len(large))
+	}
+
+	req := httptest.NewRequest("GET", "/small", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+	// Clients that don't ask for compression get plain responses
+	resp, err = app.Test(httptest.NewRequest("GET", "/pools", nil))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+}
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		return c.SendStatus(fiber.StatusRequestEntityTooLarge)
+	}})
+	app.Post("/import", BodyLimit(16), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/import", strings.NewReader("date,amount\n")))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/import", strings.NewReader(strings.Repeat("x", 17))))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusRequestEntityTooLarge, resp.StatusCode)
+}
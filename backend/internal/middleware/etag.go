@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag tags successful GET responses with a hash of their body and answers requests
+// whose If-None-Match already holds that tag with 304 Not Modified, so polling clients
+// only download data that changed. Responses are per user, so they are marked private
+// and cached for maxAge; a zero maxAge makes clients revalidate every time.
+func ETag(maxAge time.Duration) fiber.Handler {
+	cacheControl := "private, no-cache"
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		body := c.Response().Body()
+		if len(body) == 0 {
+			return nil
+		}
+		sum := sha256.Sum256(body)
+		// Weak, since compression may change the bytes sent but not their meaning
+		tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+		c.Set(fiber.HeaderETag, tag)
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+		c.Vary(fiber.HeaderAuthorization)
+
+		if etagMatches(c.Get(fiber.HeaderIfNoneMatch), tag) {
+			c.Response().ResetBody()
+			c.Status(fiber.StatusNotModified)
+		}
+		return nil
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists tag, comparing weakly
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag_NotModifiedWhenTagMatches(t *testing.T) {
+	app := setupTestApp()
+	body := `{"data":[1,2,3]}`
+	app.Get("/pools", ETag(30*time.Second), func(c *fiber.Ctx) error {
+		return c.SendString(body)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/pools", nil))
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "private, max-age=30", resp.Header.Get("Cache-Control"))
+	tag := resp.Header.Get("ETag")
+	require.NotEmpty(t, tag)
+
+	req := httptest.NewRequest("GET", "/pools", nil)
+	req.Header.Set("If-None-Match", tag)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 304, resp.StatusCode)
+	assert.Equal(t, tag, resp.Header.Get("ETag"))
+
+	// A changed body gets a new tag and is sent in full
+	body = `{"data":[1,2,3,4]}`
+	req = httptest.NewRequest("GET", "/pools", nil)
+	req.Header.Set("If-None-Match", tag)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.NotEqual(t, tag, resp.Header.Get("ETag"))
+}
+
+func TestETag_SkipsErrorsAndWrites(t *testing.T) {
+	app := setupTestApp()
+	app.Use(ETag(0))
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return c.Status(404).SendString("not found")
+	})
+	app.Post("/alerts", func(c *fiber.Ctx) error {
+		return c.Status(201).SendString("created")
+	})
+	app.Get("/alerts", func(c *fiber.Ctx) error {
+		return c.SendString("[]")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/missing", nil))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("ETag"))
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/alerts", nil))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header.Get("ETag"))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/alerts", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "private, no-cache", resp.Header.Get("Cache-Control"))
+}
+
+func TestETagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`W/"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`"x", W/"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`*`, `W/"abc"`))
+	assert.False(t, etagMatches(``, `W/"abc"`))
+	assert.False(t, etagMatches(`W/"abd"`, `W/"abc"`))
+}
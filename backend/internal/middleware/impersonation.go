@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImpersonationHeader carries an impersonation token alongside the admin's own bearer token
+const ImpersonationHeader = "X-Impersonation-Token"
+
+// ImpersonationAuthorizer checks impersonation tokens and audits their use
+type ImpersonationAuthorizer interface {
+	Authorize(ctx context.Context, adminID uuid.UUID, token string) (*models.ImpersonationSession, *models.User, error)
+	RecordRequest(ctx context.Context, session *models.ImpersonationSession, method, path string) error
+}
+
+// Impersonation lets an admin sending an impersonation token act as the user it was
+// issued for, read-only. It runs after JWTAuthWithUser and swaps the user in the
+// request's locals, keeping the admin in c.Locals("impersonatorID"). Every such request
+// is audited before it's served, and anything but a read is refused.
+func Impersonation(authorizer ImpersonationAuthorizer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get(ImpersonationHeader)
+		if token == "" {
+			return c.Next()
+		}
+
+		isAdmin, _ := c.Locals("isAdmin").(bool)
+		adminID, ok := c.Locals("userID").(uuid.UUID)
+		if !ok || !isAdmin {
+			return errors.Forbidden("Admin access required")
+		}
+
+		session, user, err := authorizer.Authorize(c.Context(), adminID, token)
+		if err != nil {
+			return err
+		}
+		// Refused requests are audited too, as attempts
+		if err := authorizer.RecordRequest(c.Context(), session, c.Method(), c.OriginalURL()); err != nil {
+			return err
+		}
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return errors.Forbidden("Impersonation is read-only")
+		}
+
+		c.Locals("address", user.Address)
+		c.Locals("userID", user.ID)
+		c.Locals("isAdmin", false)
+		c.Locals("user", user)
+		c.Locals("impersonatorID", adminID)
+		c.Locals("impersonationSessionID", session.ID)
+		c.Set("X-Impersonating", user.ID.String())
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeImpersonationAuthorizer struct {
+	session  *models.ImpersonationSession
+	user     *models.User
+	recorded []string
+}
+
+func (a *fakeImpersonationAuthorizer) Authorize(ctx context.Context, adminID uuid.UUID, token string) (*models.ImpersonationSession, *models.User, error) {
+	if token != "good" || adminID != a.session.AdminID {
+		return nil, nil, errors.Unauthorized("Invalid impersonation token")
+	}
+	return a.session, a.user, nil
+}
+
+func (a *fakeImpersonationAuthorizer) RecordRequest(ctx context.Context, session *models.ImpersonationSession, method, path string) error {
+	a.recorded = append(a.recorded, method+" "+path)
+	return nil
+}
+
+func TestImpersonation(t *testing.T) {
+	adminID := uuid.New()
+	user := &models.User{ID: uuid.New(), Address: "0xuser"}
+	authorizer := &fakeImpersonationAuthorizer{
+		session: &models.ImpersonationSession{ID: uuid.New(), AdminID: adminID, UserID: user.ID},
+		user:    user,
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		if appErr, ok := err.(*errors.AppError); ok {
+			return c.SendStatus(appErr.Status)
+		}
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", adminID)
+		c.Locals("isAdmin", c.Get("X-Test-Admin") == "true")
+		return c.Next()
+	})
+	app.Use(Impersonation(authorizer))
+	handler := func(c *fiber.Ctx) error {
+		impersonator, _ := c.Locals("impersonatorID").(uuid.UUID)
+		return c.JSON(fiber.Map{"user": c.Locals("userID"), "impersonator": impersonator, "admin": c.Locals("isAdmin")})
+	}
+	app.Get("/portfolio", handler)
+	app.Post("/alerts", handler)
+
+	request := func(method, path, token string, admin bool) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set(ImpersonationHeader, token)
+		}
+		if admin {
+			req.Header.Set("X-Test-Admin", "true")
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 200, request("GET", "/portfolio", "", false), "requests without a token pass through")
+	assert.Equal(t, 403, request("GET", "/portfolio", "good", false), "only admins can impersonate")
+	assert.Equal(t, 401, request("GET", "/portfolio", "forged", true))
+	assert.Empty(t, authorizer.recorded)
+
+	req := httptest.NewRequest("GET", "/portfolio?hideSmall=true", nil)
+	req.Header.Set(ImpersonationHeader, "good")
+	req.Header.Set("X-Test-Admin", "true")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, user.ID.String(), resp.Header.Get("X-Impersonating"))
+
+	assert.Equal(t, 403, request("POST", "/alerts", "good", true), "impersonation is read-only")
+	assert.Equal(t, []string{"GET /portfolio?hideSmall=true", "POST /alerts"}, authorizer.recorded, "refused attempts are audited too")
+}
@@ -3,25 +3,39 @@ package middleware
 import (
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/redact"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
 )
 
-func RequestLogger() fiber.Handler {
+// RequestLogger logs every request. Errors are handed to the app's error handler here
+// rather than after the middleware chain, so the logged status is the one sent. When
+// captures is set, the redacted bodies of requests that failed with 4xx or 5xx are kept
+// in it.
+func RequestLogger(captures *RequestCaptures) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
 
 		// Process request
 		err := c.Next()
+		if err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
 
 		// Calculate latency
 		latency := time.Since(start)
+		status := c.Response().StatusCode()
 
 		// Log request details
 		logger.Info("HTTP request",
 			"method", c.Method(),
 			"path", c.Path(),
-			"status", c.Response().StatusCode(),
+			"status", status,
 			"latency", latency.Milliseconds(),
 			"ip", c.IP(),
 			"user_agent", c.Get("User-Agent"),
@@ -29,6 +43,36 @@ func RequestLogger() fiber.Handler {
 			"address", c.Locals("address"), // Will be nil for unauthenticated requests
 		)
 
-		return err
+		if captures != nil && status >= fiber.StatusBadRequest {
+			captureRequest(c, captures, status, err, latency)
+		}
+		return nil
 	}
-}
\ No newline at end of file
+}
+
+// captureRequest keeps a failed request. Fiber reuses the request's buffers once it's
+// done, so everything kept is copied.
+func captureRequest(c *fiber.Ctx, captures *RequestCaptures, status int, err error, latency time.Duration) {
+	capture := models.RequestCapture{
+		Method:     utils.CopyString(c.Method()),
+		Path:       utils.CopyString(c.Path()),
+		Query:      redact.Query(string(c.Request().URI().QueryString())),
+		Status:     status,
+		LatencyMs:  latency.Milliseconds(),
+		CapturedAt: time.Now().UTC(),
+		RequestBody: capturedBody(c.Body(),
+			string(c.Request().Header.ContentType()), string(c.Request().Header.ContentEncoding())),
+		ResponseBody: capturedBody(c.Response().Body(),
+			string(c.Response().Header.ContentType()), string(c.Response().Header.Peek(fiber.HeaderContentEncoding))),
+	}
+	if requestID, ok := c.Locals("requestid").(string); ok {
+		capture.RequestID = utils.CopyString(requestID)
+	}
+	if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+		capture.UserID = &userID
+	}
+	if err != nil {
+		capture.Error = redact.Text(err.Error())
+	}
+	captures.Add(capture)
+}
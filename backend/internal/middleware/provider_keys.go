@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ProviderKeyResolver merges request-supplied provider keys with a user's stored keys
+type ProviderKeyResolver interface {
+	ResolveKeys(ctx context.Context, userID uuid.UUID, requestKeys models.ProviderKeys) models.ProviderKeys
+}
+
+// ProviderKeys resolves which provider API keys a request should use. Keys sent in
+// X-*-API-Key headers win, then the user's stored keys; anything left empty falls
+// back to the platform keys in the client factory. The result is stored in
+// c.Locals("providerKeys").
+func ProviderKeys(resolver ProviderKeyResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestKeys := models.ProviderKeys{
+			Alchemy:   c.Get("X-Alchemy-API-Key", ""),
+			CoinGecko: c.Get("X-CoinGecko-API-Key", ""),
+			Etherscan: c.Get("X-Etherscan-API-Key", ""),
+			Infura:    c.Get("X-Infura-API-Key", ""),
+		}
+
+		keys := requestKeys
+		if userID, ok := c.Locals("userID").(uuid.UUID); ok {
+			keys = resolver.ResolveKeys(c.Context(), userID, requestKeys)
+		}
+
+		c.Locals("providerKeys", keys)
+		return c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubKeyResolver struct {
+	stored models.ProviderKeys
+	calls  int
+}
+
+func (s *stubKeyResolver) ResolveKeys(ctx context.Context, userID uuid.UUID, requestKeys models.ProviderKeys) models.ProviderKeys {
+	s.calls++
+	resolved := requestKeys
+	if resolved.Alchemy == "" {
+		resolved.Alchemy = s.stored.Alchemy
+	}
+	if resolved.CoinGecko == "" {
+		resolved.CoinGecko = s.stored.CoinGecko
+	}
+	return resolved
+}
+
+func TestProviderKeys_HeaderWinsOverStoredKey(t *testing.T) {
+	resolver := &stubKeyResolver{stored: models.ProviderKeys{Alchemy: "stored-alchemy", CoinGecko: "stored-cg"}}
+	app := setupTestApp()
+
+	var got models.ProviderKeys
+	app.Get("/keys", func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.New())
+		return c.Next()
+	}, ProviderKeys(resolver), func(c *fiber.Ctx) error {
+		got = c.Locals("providerKeys").(models.ProviderKeys)
+		return c.SendStatus(200)
+	})
+
+	req := httptest.NewRequest("GET", "/keys", nil)
+	req.Header.Set("X-Alchemy-API-Key", "header-alchemy")
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, resolver.calls)
+	assert.Equal(t, "header-alchemy", got.Alchemy)
+	assert.Equal(t, "stored-cg", got.CoinGecko)
+}
+
+func TestProviderKeys_AnonymousUsesHeadersOnly(t *testing.T) {
+	resolver := &stubKeyResolver{stored: models.ProviderKeys{Alchemy: "stored-alchemy"}}
+	app := setupTestApp()
+
+	var got models.ProviderKeys
+	app.Get("/keys", ProviderKeys(resolver), func(c *fiber.Ctx) error {
+		got = c.Locals("providerKeys").(models.ProviderKeys)
+		return c.SendStatus(200)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/keys", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 0, resolver.calls)
+	assert.Empty(t, got.Alchemy)
+}
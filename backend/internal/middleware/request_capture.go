@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/redact"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxCapturedBody is how much of each body a capture keeps
+const maxCapturedBody = 16 * 1024
+
+// RequestCaptures keeps the last failed requests in a fixed-size ring so admins can see
+// what a client actually sent and got back. Captures live in the memory of the API
+// instance that served the request.
+type RequestCaptures struct {
+	mu       sync.Mutex
+	captures []models.RequestCapture
+	next     int
+	lastID   int64
+}
+
+func NewRequestCaptures(size int) *RequestCaptures {
+	if size < 1 {
+		size = 1
+	}
+	return &RequestCaptures{captures: make([]models.RequestCapture, 0, size)}
+}
+
+// Add keeps a capture, replacing the oldest once the ring is full, and returns its ID
+func (r *RequestCaptures) Add(capture models.RequestCapture) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	capture.ID = r.lastID
+	if len(r.captures) < cap(r.captures) {
+		r.captures = append(r.captures, capture)
+	} else {
+		r.captures[r.next] = capture
+	}
+	r.next = (r.next + 1) % cap(r.captures)
+	return capture.ID
+}
+
+// List returns the captures matching the filter, newest first
+func (r *RequestCaptures) List(filter models.RequestCaptureFilter) []models.RequestCapture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matches := []models.RequestCapture{}
+	for i := 1; i <= len(r.captures); i++ {
+		c := r.captures[(r.next-i+len(r.captures))%len(r.captures)]
+		if filter.Status != 0 && c.Status != filter.Status {
+			continue
+		}
+		if filter.PathPrefix != "" && !strings.HasPrefix(c.Path, filter.PathPrefix) {
+			continue
+		}
+		if filter.RequestID != "" && c.RequestID != filter.RequestID {
+			continue
+		}
+		if filter.UserID != nil && (c.UserID == nil || *c.UserID != *filter.UserID) {
+			continue
+		}
+		matches = append(matches, c)
+		if filter.Limit > 0 && len(matches) == filter.Limit {
+			break
+		}
+	}
+	return matches
+}
+
+// Get returns the capture with the ID, or nil once it's been replaced
+func (r *RequestCaptures) Get(id int64) *models.RequestCapture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.captures {
+		if c.ID == id {
+			return &c
+		}
+	}
+	return nil
+}
+
+// capturedBody returns a body fit to keep: redacted, truncated, and left out when it
+// isn't text
+func capturedBody(body []byte, contentType, contentEncoding string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if contentEncoding != "" {
+		return fmt.Sprintf("[%d bytes %s encoded]", len(body), contentEncoding)
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case mediaType == fiber.MIMEApplicationJSON:
+		body = redact.JSON(body)
+	case mediaType == fiber.MIMEApplicationForm:
+		body = []byte(redact.Query(string(body)))
+	case mediaType == "", strings.HasPrefix(mediaType, "text/"):
+		body = []byte(redact.Text(string(body)))
+	default:
+		return fmt.Sprintf("[%d bytes %s]", len(body), mediaType)
+	}
+
+	if len(body) > maxCapturedBody {
+		return string(body[:maxCapturedBody]) + fmt.Sprintf("...[%d bytes truncated]", len(body)-maxCapturedBody)
+	}
+	return string(body)
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/redact"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger_CapturesFailedRequests(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		e := err.(*errors.AppError)
+		return c.Status(e.Status).JSON(e)
+	}})
+	captures := NewRequestCaptures(10)
+	app.Use(RequestLogger(captures))
+	app.Post("/auth/verify", func(c *fiber.Ctx) error {
+		return errors.Unauthorized("Invalid signature from alice@example.com")
+	})
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"ok": true})
+	})
+
+	sig := "0x" + strings.Repeat("cd", 65)
+	req := httptest.NewRequest("POST", "/auth/verify?token=abc", strings.NewReader(`{"message":"hi","signature":"`+sig+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, 401, resp.StatusCode)
+
+	_, err = app.Test(httptest.NewRequest("GET", "/ok", nil))
+	require.NoError(t, err)
+
+	// Only the failure is kept, with the status the error handler sent
+	list := captures.List(models.RequestCaptureFilter{})
+	require.Len(t, list, 1)
+	capture := list[0]
+	assert.Equal(t, "POST", capture.Method)
+	assert.Equal(t, "/auth/verify", capture.Path)
+	assert.Equal(t, 401, capture.Status)
+	assert.Equal(t, "token="+redact.Redacted, capture.Query)
+	assert.NotContains(t, capture.RequestBody, sig)
+	assert.Contains(t, capture.RequestBody, `"message":"hi"`)
+	assert.NotContains(t, capture.ResponseBody, "alice@example.com")
+	assert.NotContains(t, capture.Error, "alice@example.com")
+	assert.Equal(t, capture, *captures.Get(capture.ID))
+}
+
+func TestRequestCaptures_Ring(t *testing.T) {
+	captures := NewRequestCaptures(3)
+	for i := 0; i < 5; i++ {
+		captures.Add(models.RequestCapture{Path: fmt.Sprintf("/r/%d", i), Status: 400 + i%2*100})
+	}
+
+	// The oldest are replaced and listings are newest first
+	list := captures.List(models.RequestCaptureFilter{})
+	require.Len(t, list, 3)
+	assert.Equal(t, []string{"/r/4", "/r/3", "/r/2"}, []string{list[0].Path, list[1].Path, list[2].Path})
+	assert.Nil(t, captures.Get(1))
+	assert.NotNil(t, captures.Get(5))
+
+	list = captures.List(models.RequestCaptureFilter{Status: 500})
+	require.Len(t, list, 1)
+	assert.Equal(t, "/r/3", list[0].Path)
+
+	assert.Len(t, captures.List(models.RequestCaptureFilter{Limit: 2}), 2)
+	assert.Empty(t, captures.List(models.RequestCaptureFilter{PathPrefix: "/other"}))
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/defi-dashboard/backend/pkg/address"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
+)
+
+// UsageObserver watches request patterns for scraping and refuses requests from users
+// or API keys it has restricted
+type UsageObserver interface {
+	Observe(ctx context.Context, userID uuid.UUID, apiKey string, addresses []string) error
+}
+
+// providerKeyHeaders are the headers requests send their own provider API keys in
+var providerKeyHeaders = []string{"X-Alchemy-API-Key", "X-CoinGecko-API-Key", "X-Etherscan-API-Key", "X-Infura-API-Key"}
+
+// UsageGuard reports each authenticated request to the observer: the user, the first
+// provider API key sent, and the EVM addresses in the path or address query parameter.
+// Requests made while impersonating aren't the user's own and are left out.
+func UsageGuard(observer UsageObserver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("userID").(uuid.UUID)
+		if !ok || c.Locals("impersonatorID") != nil {
+			return c.Next()
+		}
+
+		var apiKey string
+		for _, header := range providerKeyHeaders {
+			if key := c.Get(header); key != "" {
+				// Fiber reuses the header's buffer once the request is done
+				apiKey = utils.CopyString(key)
+				break
+			}
+		}
+
+		if err := observer.Observe(c.Context(), userID, apiKey, requestAddresses(c)); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// requestAddresses returns the EVM addresses a request looks up, normalized
+func requestAddresses(c *fiber.Ctx) []string {
+	var addresses []string
+	for _, segment := range strings.Split(c.Path(), "/") {
+		if address.IsEVM(segment) {
+			addresses = append(addresses, address.Normalize(segment))
+		}
+	}
+	if query := c.Query("address"); address.IsEVM(query) {
+		addresses = append(addresses, address.Normalize(query))
+	}
+	return addresses
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type usageCall struct {
+	userID    uuid.UUID
+	apiKey    string
+	addresses []string
+}
+
+type recordingUsageObserver struct {
+	calls []usageCall
+}
+
+func (o *recordingUsageObserver) Observe(ctx context.Context, userID uuid.UUID, apiKey string, addresses []string) error {
+	o.calls = append(o.calls, usageCall{userID, apiKey, addresses})
+	return nil
+}
+
+func TestUsageGuard(t *testing.T) {
+	userID := uuid.New()
+	observer := &recordingUsageObserver{}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get("X-Test-Anonymous") == "" {
+			c.Locals("userID", userID)
+		}
+		if c.Get("X-Test-Impersonating") != "" {
+			c.Locals("impersonatorID", uuid.New())
+		}
+		return c.Next()
+	})
+	app.Use(UsageGuard(observer))
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+
+	request := func(path string, headers map[string]string) {
+		req := httptest.NewRequest("GET", path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+	}
+
+	request("/api/v1/portfolio/0xAbC0000000000000000000000000000000000001/balances", map[string]string{"X-CoinGecko-API-Key": "cg-key"})
+	request("/api/v1/analytics/pnl?address=0x0000000000000000000000000000000000000002", nil)
+	request("/api/v1/wallets", nil)
+	request("/api/v1/wallets", map[string]string{"X-Test-Anonymous": "1"})
+	request("/api/v1/portfolio/0x0000000000000000000000000000000000000003/balances", map[string]string{"X-Test-Impersonating": "1"})
+
+	require.Len(t, observer.calls, 3, "anonymous and impersonated requests aren't observed")
+	assert.Equal(t, usageCall{userID, "cg-key", []string{"0xabc0000000000000000000000000000000000001"}}, observer.calls[0])
+	assert.Equal(t, []string{"0x0000000000000000000000000000000000000002"}, observer.calls[1].addresses)
+	assert.Empty(t, observer.calls[2].addresses)
+}
@@ -0,0 +1,362 @@
+package mockproviders
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+)
+
+// Bridge and swap fee rates taken out of quoted amounts
+const (
+	bridgeFee = 0.0015
+	swapFee   = 0.003
+)
+
+// bridgeRouter is the contract fake bridge transactions are sent to
+const bridgeRouter = "0x1231deb6f5749ef6ce6943a275a1d3e7486f4eae"
+
+// quoteGas is the gas limit fake quotes estimate
+const quoteGas = 250000
+
+// chainIDs returns the served chains in ascending order
+func chainIDs() []int {
+	ids := make([]int, 0, len(chains))
+	for id := range chains {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// mainnetID returns the mainnet a chain belongs to
+func mainnetID(chainID int) int {
+	if chainID == 80002 {
+		return 137
+	}
+	return chainID
+}
+
+// gasCost returns the native cost of a quote's transaction in wei and USD
+func (m *market) gasCost(chainID int, t time.Time) (string, float64) {
+	wei := new(big.Int).Mul(big.NewInt(quoteGas), big.NewInt(chains[chainID].GasPrice))
+	native, _ := m.nativeToken(chainID)
+	return wei.String(), m.usdValue(native, wei.String(), t)
+}
+
+// quoteParams reads the chains, tokens and amount of a quote request
+func quoteParams(r *http.Request, fromChain, toChain, fromToken, toToken, amount string) (int, int, string, string, string) {
+	q := r.URL.Query()
+	from, _ := strconv.Atoi(q.Get(fromChain))
+	to, _ := strconv.Atoi(q.Get(toChain))
+	return from, to, q.Get(fromToken), q.Get(toToken), q.Get(amount)
+}
+
+func (t *Transport) lifiToken(token fixtures.Token, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"address":  strings.ToLower(token.Address),
+		"chainId":  token.ChainID,
+		"symbol":   token.Symbol,
+		"name":     token.Name,
+		"decimals": token.Decimals,
+		"logoURI":  "",
+		"priceUSD": strconv.FormatFloat(t.market.tokenPrice(token, now), 'f', -1, 64),
+	}
+}
+
+// lifi serves the LI.FI bridge aggregator
+func (t *Transport) lifi() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /quote", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		fromChain, toChain, fromAddress, toAddress, amount := quoteParams(r, "fromChain", "toChain", "fromToken", "toToken", "fromAmount")
+		fee := bridgeFee
+		if fromChain == toChain {
+			fee = swapFee
+		}
+		from, to, out, ok := t.market.convert(fromChain, fromAddress, toChain, toAddress, amount, fee, now)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "No available quotes for the requested transfer", "code": 1002})
+			return
+		}
+
+		stepType, tool, duration := "cross", "stargate", 180
+		if fromChain == toChain {
+			stepType, tool, duration = "swap", "uniswap", 30
+		}
+		native, _ := t.market.nativeToken(fromChain)
+		gasWei, gasUSD := t.market.gasCost(fromChain, now)
+		value := "0"
+		if from.Address == fixtures.NativeToken {
+			value = amount
+		}
+		feeAmount := t.market.usdValue(from, amount, now) * fee
+		userAddress := r.URL.Query().Get("fromAddress")
+
+		step := map[string]interface{}{
+			"id":   requestID("lifi-step", r.URL.RawQuery),
+			"type": stepType,
+			"tool": tool,
+			"action": map[string]interface{}{
+				"fromChainId": fromChain,
+				"toChainId":   toChain,
+				"fromToken":   t.lifiToken(from, now),
+				"toToken":     t.lifiToken(to, now),
+				"fromAmount":  amount,
+				"toAmount":    out,
+				"slippage":    0.005,
+				"fromAddress": userAddress,
+				"toAddress":   r.URL.Query().Get("toAddress"),
+			},
+			"estimate": map[string]interface{}{
+				"fromAmount":        amount,
+				"toAmount":          out,
+				"toAmountMin":       out,
+				"executionDuration": duration,
+				"approvalAddress":   bridgeRouter,
+				"feeCosts": []map[string]interface{}{{
+					"name":        tool + " fee",
+					"description": "Fee charged by the bridge",
+					"token":       t.lifiToken(from, now),
+					"amount":      "0",
+					"amountUSD":   fmt.Sprintf("%.2f", feeAmount),
+					"percentage":  strconv.FormatFloat(fee, 'f', -1, 64),
+					"included":    true,
+				}},
+				"gasCosts": []map[string]interface{}{{
+					"type":      "SEND",
+					"price":     strconv.FormatInt(chains[fromChain].GasPrice, 10),
+					"estimate":  strconv.Itoa(quoteGas),
+					"limit":     strconv.Itoa(quoteGas),
+					"amount":    gasWei,
+					"amountUSD": fmt.Sprintf("%.2f", gasUSD),
+					"token":     t.lifiToken(native, now),
+				}},
+			},
+			"transactionRequest": map[string]interface{}{
+				"data":     "0x",
+				"to":       bridgeRouter,
+				"value":    value,
+				"from":     userAddress,
+				"chainId":  fromChain,
+				"gasLimit": strconv.Itoa(quoteGas),
+				"gasPrice": strconv.FormatInt(chains[fromChain].GasPrice, 10),
+			},
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"routes": []map[string]interface{}{{
+			"id":          requestID("lifi", r.URL.RawQuery),
+			"fromChainId": fromChain,
+			"toChainId":   toChain,
+			"fromToken":   t.lifiToken(from, now),
+			"toToken":     t.lifiToken(to, now),
+			"fromAmount":  amount,
+			"toAmount":    out,
+			"steps":       []map[string]interface{}{step},
+			"tags":        []string{"RECOMMENDED", "CHEAPEST"},
+			"gasCostUSD":  fmt.Sprintf("%.2f", gasUSD),
+		}}})
+	})
+
+	mux.HandleFunc("GET /chains", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		var list []map[string]interface{}
+		for _, id := range chainIDs() {
+			native, _ := t.market.nativeToken(id)
+			list = append(list, map[string]interface{}{
+				"id":          id,
+				"key":         chains[id].Key,
+				"name":        chains[id].Name,
+				"coin":        chains[id].Native,
+				"mainnetId":   mainnetID(id),
+				"nativeToken": t.lifiToken(native, now),
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"chains": list})
+	})
+
+	mux.HandleFunc("GET /tokens", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		tokens := make(map[string][]map[string]interface{})
+		for _, id := range strings.Split(r.URL.Query().Get("chains"), ",") {
+			chainID, err := strconv.Atoi(strings.TrimSpace(id))
+			if err != nil {
+				continue
+			}
+			list := []map[string]interface{}{}
+			for _, token := range t.market.tokens[chainID] {
+				list = append(list, t.lifiToken(token, now))
+			}
+			tokens[strconv.Itoa(chainID)] = list
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+	})
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		hash := q.Get("txHash")
+		if hash == "" {
+			writeJSON(w, http.StatusOK, map[string]string{"status": "NOT_FOUND"})
+			return
+		}
+		// Fake transfers land as soon as they're looked up
+		toChain, _ := strconv.Atoi(q.Get("toChain"))
+		if toChain == 0 {
+			toChain, _ = strconv.Atoi(q.Get("fromChain"))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":    "DONE",
+			"substatus": "COMPLETED",
+			"tool":      "stargate",
+			"sending":   map[string]interface{}{"txHash": hash},
+			"receiving": map[string]interface{}{"txHash": requestID("lifi-receive", strings.ToLower(hash)), "chainId": toChain},
+		})
+	})
+
+	return mux
+}
+
+func (t *Transport) socketToken(token fixtures.Token) map[string]interface{} {
+	return map[string]interface{}{
+		"chainId":  token.ChainID,
+		"address":  strings.ToLower(token.Address),
+		"name":     token.Name,
+		"symbol":   token.Symbol,
+		"decimals": token.Decimals,
+		"icon":     "",
+		"logoURI":  "",
+	}
+}
+
+// socket serves the Socket bridge aggregator
+func (t *Transport) socket() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /quote", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		fromChain, toChain, fromAddress, toAddress, amount := quoteParams(r, "fromChainId", "toChainId", "fromTokenAddress", "toTokenAddress", "fromAmount")
+		from, to, out, ok := t.market.convert(fromChain, fromAddress, toChain, toAddress, amount, bridgeFee, now)
+		if !ok {
+			// Socket answers an unroutable pair with an empty route list
+			writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "result": map[string]interface{}{"routes": []interface{}{}}})
+			return
+		}
+
+		native, _ := t.market.nativeToken(fromChain)
+		gasWei, gasUSD := t.market.gasCost(fromChain, now)
+		userAddress := r.URL.Query().Get("userAddress")
+		route := map[string]interface{}{
+			"routeId":           requestID("socket", r.URL.RawQuery),
+			"isOnlySwapRoute":   fromChain == toChain,
+			"fromAmount":        amount,
+			"toAmount":          out,
+			"usedBridgeNames":   []string{"hop"},
+			"totalUserTx":       1,
+			"sender":            userAddress,
+			"recipient":         userAddress,
+			"totalGasFeesInUsd": gasUSD,
+			"inputValueInUsd":   t.market.usdValue(from, amount, now),
+			"outputValueInUsd":  t.market.usdValue(to, out, now),
+			"receiveValueInUsd": t.market.usdValue(to, out, now),
+			"serviceTime":       240,
+			"maxServiceTime":    1200,
+			"integratorFee":     map[string]interface{}{"amount": "0", "asset": t.socketToken(from)},
+			"userTxs": []map[string]interface{}{{
+				"userTxType": "fund-movr",
+				"txType":     "eth_sendTransaction",
+				"chainId":    fromChain,
+				"toAmount":   out,
+				"toAsset":    t.socketToken(to),
+				"stepCount":  1,
+				"routePath":  "0-hop",
+				"sender":     userAddress,
+				"steps": []map[string]interface{}{{
+					"type":           "bridge",
+					"protocol":       map[string]string{"name": "hop", "displayName": "Hop", "icon": ""},
+					"fromChainId":    fromChain,
+					"fromAsset":      t.socketToken(from),
+					"fromAmount":     amount,
+					"toChainId":      toChain,
+					"toAsset":        t.socketToken(to),
+					"toAmount":       out,
+					"bridgeSlippage": 0.5,
+					"serviceTime":    240,
+					"maxServiceTime": 1200,
+				}},
+				"gasFees": map[string]interface{}{
+					"gasAmount": gasWei,
+					"gasLimit":  quoteGas,
+					"asset":     t.socketToken(native),
+					"feesInUsd": gasUSD,
+				},
+			}},
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "result": map[string]interface{}{
+			"routes":      []map[string]interface{}{route},
+			"fromChainId": fromChain,
+			"fromAsset":   t.socketToken(from),
+			"toChainId":   toChain,
+			"toAsset":     t.socketToken(to),
+			"fromAmount":  amount,
+		}})
+	})
+
+	mux.HandleFunc("GET /supported/chains", func(w http.ResponseWriter, r *http.Request) {
+		var list []map[string]interface{}
+		for _, id := range chainIDs() {
+			if mainnetID(id) != id {
+				continue
+			}
+			native, _ := t.market.nativeToken(id)
+			currency := t.socketToken(native)
+			list = append(list, map[string]interface{}{
+				"chainId":          id,
+				"name":             chains[id].Name,
+				"isL1":             id == 1,
+				"sendingEnabled":   true,
+				"receivingEnabled": true,
+				"currency":         currency,
+				"rpcs":             []string{},
+				"explorers":        []string{},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "result": list})
+	})
+
+	mux.HandleFunc("GET /token-lists/from-token-list", func(w http.ResponseWriter, r *http.Request) {
+		chainID, _ := strconv.Atoi(r.URL.Query().Get("fromChainId"))
+		list := []map[string]interface{}{}
+		for _, token := range t.market.tokens[chainID] {
+			entry := t.socketToken(token)
+			entry["currency"] = token.Symbol
+			list = append(list, entry)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "result": map[string]interface{}{
+			"fromChainId": chainID,
+			"toChainId":   chainID,
+			"result":      list,
+		}})
+	})
+
+	mux.HandleFunc("GET /bridge-status", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		fromChain, _ := strconv.Atoi(q.Get("fromChainId"))
+		toChain, _ := strconv.Atoi(q.Get("toChainId"))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "result": map[string]interface{}{
+			"sourceTx":                   q.Get("transactionHash"),
+			"sourceTxStatus":             "COMPLETED",
+			"destinationTransactionHash": requestID("socket-receive", strings.ToLower(q.Get("transactionHash"))),
+			"destinationTxStatus":        "COMPLETED",
+			"fromChainId":                fromChain,
+			"toChainId":                  toChain,
+			"bridgeName":                 "hop",
+		}})
+	})
+
+	return mux
+}
@@ -0,0 +1,158 @@
+package mockproviders
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/external"
+)
+
+// coinGecko serves the CoinGecko endpoints the price and metadata clients use
+func (t *Transport) coinGecko() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /simple/price", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		prices := make(map[string]external.TokenPrice)
+		for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			if c, ok := t.market.coins[strings.TrimSpace(id)]; ok {
+				prices[c.ID] = external.TokenPrice{USD: c.price(now), USD24hChange: c.change24h(now)}
+			}
+		}
+		writeJSON(w, http.StatusOK, prices)
+	})
+
+	// Trending coins are the ones moving most over the day, standing in for the most searched
+	mux.HandleFunc("GET /search/trending", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		coins := make([]coin, 0, len(t.market.coins))
+		for _, c := range t.market.coins {
+			coins = append(coins, c)
+		}
+		sort.Slice(coins, func(i, j int) bool {
+			a, b := math.Abs(coins[i].change24h(now)), math.Abs(coins[j].change24h(now))
+			if a != b {
+				return a > b
+			}
+			return coins[i].ID < coins[j].ID
+		})
+		if len(coins) > 7 {
+			coins = coins[:7]
+		}
+
+		items := make([]map[string]interface{}, 0, len(coins))
+		for i, c := range coins {
+			items = append(items, map[string]interface{}{"item": map[string]interface{}{
+				"id":              c.ID,
+				"name":            c.Name,
+				"symbol":          strings.ToUpper(c.Symbol),
+				"market_cap_rank": i + 1,
+				"thumb":           "",
+				"score":           i,
+				"data": map[string]interface{}{
+					"price":                       c.price(now),
+					"price_change_percentage_24h": map[string]float64{"usd": c.change24h(now)},
+				},
+			}})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"coins": items, "nfts": []interface{}{}, "categories": []interface{}{}})
+	})
+
+	mux.HandleFunc("GET /coins/{id}/market_chart", func(w http.ResponseWriter, r *http.Request) {
+		c, ok := t.market.coins[r.PathValue("id")]
+		if !ok {
+			coinNotFound(w)
+			return
+		}
+		days, err := strconv.Atoi(r.URL.Query().Get("days"))
+		if err != nil || days <= 0 {
+			days = 1
+		}
+		// CoinGecko returns hourly points for up to 90 days and daily points beyond
+		step := time.Hour
+		if days > 90 {
+			step = 24 * time.Hour
+		}
+		now := t.clock.Now()
+		var prices [][]float64
+		for at := now.Add(-time.Duration(days) * 24 * time.Hour).Truncate(step); !at.After(now); at = at.Add(step) {
+			prices = append(prices, []float64{float64(at.UnixMilli()), c.price(at)})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"prices": prices})
+	})
+
+	mux.HandleFunc("GET /coins/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		c, ok := t.market.coins[r.PathValue("id")]
+		if !ok {
+			coinNotFound(w)
+			return
+		}
+		date, err := time.Parse("02-01-2006", r.URL.Query().Get("date"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid date"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":          c.ID,
+			"symbol":      c.Symbol,
+			"name":        c.Name,
+			"market_data": map[string]interface{}{"current_price": map[string]float64{"usd": c.price(date)}},
+		})
+	})
+
+	mux.HandleFunc("GET /coins/{platform}/contract/{address}", func(w http.ResponseWriter, r *http.Request) {
+		for chainID, platform := range external.AssetPlatforms {
+			if platform != r.PathValue("platform") {
+				continue
+			}
+			token, ok := t.market.token(chainID, r.PathValue("address"))
+			if !ok {
+				break
+			}
+			if id, ok := external.TokenIDMappings[strings.ToLower(token.Symbol)]; ok {
+				if c, ok := t.market.coins[id]; ok {
+					writeJSON(w, http.StatusOK, coinInfo(c))
+					return
+				}
+			}
+		}
+		coinNotFound(w)
+	})
+
+	mux.HandleFunc("GET /coins/{id}", func(w http.ResponseWriter, r *http.Request) {
+		c, ok := t.market.coins[r.PathValue("id")]
+		if !ok {
+			coinNotFound(w)
+			return
+		}
+		writeJSON(w, http.StatusOK, coinInfo(c))
+	})
+
+	return mux
+}
+
+// coinInfo describes a coin the way /coins/{id} does
+func coinInfo(c coin) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          c.ID,
+		"symbol":      c.Symbol,
+		"name":        c.Name,
+		"categories":  []string{},
+		"description": map[string]string{"en": c.Name + " is a mock provider coin."},
+		"links": map[string]interface{}{
+			"homepage":  []string{"https://example.com/" + c.ID},
+			"chat_url":  []string{},
+			"repos_url": map[string][]string{"github": {}},
+		},
+		"image":       map[string]string{"large": ""},
+		"market_data": nil,
+	}
+}
+
+func coinNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "coin not found"})
+}
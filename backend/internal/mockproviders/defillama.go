@@ -0,0 +1,61 @@
+package mockproviders
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/defi-dashboard/backend/pkg/external"
+)
+
+// defiLlama serves the fixture yield pools and their protocols
+func (t *Transport) defiLlama() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /pools", func(w http.ResponseWriter, r *http.Request) {
+		pools := make([]external.YieldPool, 0, len(t.market.fixtures.YieldPools))
+		for _, pool := range t.market.fixtures.YieldPools {
+			exposure := "single"
+			if strings.Contains(pool.Symbol, "-") {
+				exposure = "multi"
+			}
+			pools = append(pools, external.YieldPool{
+				Pool:       pool.PoolID,
+				Project:    pool.ProtocolSlug,
+				Symbol:     pool.Symbol,
+				Chain:      chains[pool.ChainID].Name,
+				TVL:        pool.TVLUSD,
+				APY:        pool.APYBase + pool.APYReward,
+				APYBase:    pool.APYBase,
+				APYReward:  pool.APYReward,
+				Exposure:   exposure,
+				StableCoin: pool.StableCoin,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": pools})
+	})
+
+	mux.HandleFunc("GET /protocols", func(w http.ResponseWriter, r *http.Request) {
+		type protocol struct {
+			Name      string             `json:"name"`
+			Slug      string             `json:"slug"`
+			Category  string             `json:"category"`
+			TVL       float64            `json:"tvl"`
+			ChainTVLs map[string]float64 `json:"chainTvls"`
+		}
+		var protocols []*protocol
+		bySlug := make(map[string]*protocol)
+		for _, pool := range t.market.fixtures.YieldPools {
+			p, ok := bySlug[pool.ProtocolSlug]
+			if !ok {
+				p = &protocol{Name: pool.Protocol, Slug: pool.ProtocolSlug, Category: "Yield", ChainTVLs: make(map[string]float64)}
+				bySlug[pool.ProtocolSlug] = p
+				protocols = append(protocols, p)
+			}
+			p.TVL += pool.TVLUSD
+			p.ChainTVLs[chains[pool.ChainID].Name] += pool.TVLUSD
+		}
+		writeJSON(w, http.StatusOK, protocols)
+	})
+
+	return mux
+}
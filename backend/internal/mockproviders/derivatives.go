@@ -0,0 +1,56 @@
+package mockproviders
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// gmxReader is the GMX v2 Reader on Arbitrum, read for accounts' positions
+const gmxReader = "0x5ca84c34a381434786738735265b9f3fd814b824"
+
+// emptyABIArray is an ABI-encoded empty dynamic array: its offset, then a zero length
+var emptyABIArray = "0x" + strings.Repeat("0", 62) + "20" + strings.Repeat("0", 64)
+
+// hyperliquid serves the Hyperliquid info endpoint. Nobody in the fake world trades
+// perpetuals, so every user has no positions and no funding.
+func (t *Transport) hyperliquid() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /info", func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Type string `json:"type"`
+		}
+		json.NewDecoder(r.Body).Decode(&query)
+
+		switch query.Type {
+		case "clearinghouseState":
+			writeJSON(w, http.StatusOK, map[string]interface{}{"assetPositions": []interface{}{}})
+		case "userFunding":
+			writeJSON(w, http.StatusOK, []interface{}{})
+		default:
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "unsupported info type"})
+		}
+	})
+
+	return mux
+}
+
+// gmx serves GMX's Arbitrum API with no listed markets or tokens
+func (t *Transport) gmx() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /tokens", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": []interface{}{}})
+	})
+
+	mux.HandleFunc("GET /prices/tickers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []interface{}{})
+	})
+
+	mux.HandleFunc("GET /markets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"markets": []interface{}{}})
+	})
+
+	return mux
+}
@@ -0,0 +1,239 @@
+package mockproviders
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/external"
+)
+
+// chain is an EVM chain the fake providers serve
+type chain struct {
+	ID        int
+	Key       string // LI.FI chain key
+	Name      string // DefiLlama chain name
+	Native    string
+	GasPrice  int64 // wei
+	BlockTime time.Duration
+	// Genesis is the block at epoch, the head the fixtures date their transactions from
+	Genesis int64
+}
+
+var chains = map[int]chain{
+	1:     {ID: 1, Key: "eth", Name: "Ethereum", Native: "ETH", GasPrice: 18_000_000_000, BlockTime: 12 * time.Second, Genesis: 21_500_000},
+	10:    {ID: 10, Key: "opt", Name: "Optimism", Native: "ETH", GasPrice: 1_000_000, BlockTime: 2 * time.Second, Genesis: 128_000_000},
+	137:   {ID: 137, Key: "pol", Name: "Polygon", Native: "MATIC", GasPrice: 45_000_000_000, BlockTime: 2 * time.Second, Genesis: 65_000_000},
+	42161: {ID: 42161, Key: "arb", Name: "Arbitrum", Native: "ETH", GasPrice: 10_000_000, BlockTime: 250 * time.Millisecond, Genesis: 280_000_000},
+	80002: {ID: 80002, Key: "amoy", Name: "Polygon Amoy", Native: "MATIC", GasPrice: 30_000_000_000, BlockTime: 2 * time.Second, Genesis: 12_000_000},
+}
+
+// epoch is when every chain was at its genesis block, so heads only move forward
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// head returns a chain's latest block at t
+func (c chain) head(t time.Time) int64 {
+	return c.Genesis + int64(t.Sub(epoch)/c.BlockTime)
+}
+
+// blockHash returns the hash of a block; blocks never change, so there are no reorgs
+func blockHash(chainID int, number int64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("mock:block:%d:%d", chainID, number)))
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+// coin is a CoinGecko coin with the price its scripted movement swings around
+type coin struct {
+	ID     string
+	Symbol string
+	Name   string
+	Price  float64
+}
+
+// extraCoins are priced coins beyond the fixture tokens
+var extraCoins = []coin{
+	{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Price: 64210.5},
+	{ID: "tether", Symbol: "usdt", Name: "Tether", Price: 1.0},
+	{ID: "uniswap", Symbol: "uni", Name: "Uniswap", Price: 9.84},
+	{ID: "aave", Symbol: "aave", Name: "Aave", Price: 152.3},
+	{ID: "chainlink", Symbol: "link", Name: "Chainlink", Price: 17.6},
+	{ID: "wrapped-steth", Symbol: "wsteth", Name: "Wrapped stETH", Price: 3712.8},
+	{ID: "rocket-pool-eth", Symbol: "reth", Name: "Rocket Pool ETH", Price: 3490.1},
+	{ID: "coinbase-wrapped-staked-eth", Symbol: "cbeth", Name: "Coinbase Wrapped Staked ETH", Price: 3341.6},
+	{ID: "cosmos", Symbol: "atom", Name: "Cosmos Hub", Price: 8.91},
+	{ID: "osmosis", Symbol: "osmo", Name: "Osmosis", Price: 0.74},
+	{ID: "celestia", Symbol: "tia", Name: "Celestia", Price: 9.38},
+}
+
+// market is the fake world every provider answers from: the fixture tokens and
+// balances, and coin prices that move on a fixed script
+type market struct {
+	fixtures *fixtures.Set
+	coins    map[string]coin
+	tokens   map[int][]fixtures.Token
+}
+
+func newMarket() *market {
+	// Tokens, wallets and balances don't depend on the fixture time
+	set := fixtures.Default(epoch)
+	m := &market{
+		fixtures: set,
+		coins:    make(map[string]coin),
+		tokens:   make(map[int][]fixtures.Token),
+	}
+	for _, token := range set.Tokens {
+		m.tokens[token.ChainID] = append(m.tokens[token.ChainID], token)
+		if id, ok := external.TokenIDMappings[strings.ToLower(token.Symbol)]; ok {
+			if _, seen := m.coins[id]; !seen {
+				m.coins[id] = coin{ID: id, Symbol: strings.ToLower(token.Symbol), Name: token.Name, Price: token.PriceUSD}
+			}
+		}
+	}
+	for _, c := range extraCoins {
+		m.coins[c.ID] = c
+	}
+	return m
+}
+
+// price returns a coin's USD price at t. Prices drift on a weekly wave with a faster
+// intraday ripple, offset per coin; stablecoins stay within a few basis points of the peg.
+func (c coin) price(t time.Time) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(c.ID))
+	phase := float64(h.Sum32()%360) * math.Pi / 180
+
+	weekly, intraday := 0.08, 0.015
+	if c.Price > 0.97 && c.Price < 1.03 {
+		weekly, intraday = 0.0004, 0.0002
+	}
+	hours := t.Sub(epoch).Hours()
+	move := weekly*math.Sin(2*math.Pi*hours/(7*24)+phase) + intraday*math.Sin(2*math.Pi*hours/6+phase)
+	return math.Round(c.Price*(1+move)*1e6) / 1e6
+}
+
+// change24h returns a coin's price change over the day before t, in percent
+func (c coin) change24h(t time.Time) float64 {
+	before := c.price(t.Add(-24 * time.Hour))
+	return (c.price(t)/before - 1) * 100
+}
+
+// nativePlaceholder is the address aggregators use for a chain's gas token
+const nativePlaceholder = "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"
+
+// token looks up a fixture token by chain and address
+func (m *market) token(chainID int, address string) (fixtures.Token, bool) {
+	if strings.EqualFold(address, nativePlaceholder) {
+		address = fixtures.NativeToken
+	}
+	for _, token := range m.tokens[chainID] {
+		if strings.EqualFold(token.Address, address) {
+			return token, true
+		}
+	}
+	return fixtures.Token{}, false
+}
+
+// nativeToken returns the gas token of a chain
+func (m *market) nativeToken(chainID int) (fixtures.Token, bool) {
+	return m.token(chainID, fixtures.NativeToken)
+}
+
+// tokenPrice returns a token's USD price at t, moving with its coin when it has one
+func (m *market) tokenPrice(token fixtures.Token, t time.Time) float64 {
+	if id, ok := external.TokenIDMappings[strings.ToLower(token.Symbol)]; ok {
+		if c, ok := m.coins[id]; ok {
+			return c.price(t)
+		}
+	}
+	return token.PriceUSD
+}
+
+// holding is an amount of a token in whole units
+type holding struct {
+	Token  fixtures.Token
+	Amount float64
+}
+
+// defaultHoldings are what any address outside the fixtures holds on each chain
+var defaultHoldings = map[string]float64{"native": 1.5, "USDC": 1000}
+
+// holdings returns what an address holds on a chain: the fixture balances for the demo
+// users, and a fixed set for any other address
+func (m *market) holdings(address string, chainID int) []holding {
+	var held []holding
+	known := false
+	for _, wallet := range m.fixtures.Wallets {
+		if !strings.EqualFold(wallet.Address, address) {
+			continue
+		}
+		known = true
+		if wallet.ChainID != chainID {
+			continue
+		}
+		for _, balance := range m.fixtures.Balances {
+			if balance.WalletID != wallet.ID {
+				continue
+			}
+			for _, token := range m.tokens[chainID] {
+				if token.ID == balance.TokenID {
+					held = append(held, holding{Token: token, Amount: balance.Amount})
+				}
+			}
+		}
+	}
+	if known {
+		return held
+	}
+
+	for _, token := range m.tokens[chainID] {
+		switch {
+		case token.Address == fixtures.NativeToken:
+			held = append(held, holding{Token: token, Amount: defaultHoldings["native"]})
+		case defaultHoldings[token.Symbol] > 0:
+			held = append(held, holding{Token: token, Amount: defaultHoldings[token.Symbol]})
+		}
+	}
+	return held
+}
+
+// convert returns how many base units of to an amount of from is worth at t, less a fee
+// rate. Either token being unknown means there is no market between them.
+func (m *market) convert(fromChain int, fromAddress string, toChain int, toAddress string, amount string, fee float64, t time.Time) (from, to fixtures.Token, out string, ok bool) {
+	from, ok = m.token(fromChain, fromAddress)
+	if !ok {
+		return from, to, "", false
+	}
+	to, ok = m.token(toChain, toAddress)
+	if !ok {
+		return from, to, "", false
+	}
+	in, err := decimal.Parse(amount)
+	if err != nil || in.Sign() <= 0 {
+		return from, to, "", false
+	}
+
+	rate := m.tokenPrice(from, t) / m.tokenPrice(to, t) * (1 - fee)
+	value := in.Mul(decimal.NewFromFloat(rate)).Mul(decimal.New(1, int32(from.Decimals-to.Decimals)))
+	return from, to, value.Round(0).String(), true
+}
+
+// usdValue returns the USD value of an amount of a token in base units at t
+func (m *market) usdValue(token fixtures.Token, amount string, t time.Time) float64 {
+	in, err := decimal.Parse(amount)
+	if err != nil {
+		return 0
+	}
+	return in.Mul(decimal.New(1, int32(token.Decimals))).Float64() * m.tokenPrice(token, t)
+}
+
+// requestID derives a stable identifier from the parts of a request
+func requestID(parts ...interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(parts...)))
+	return "0x" + hex.EncodeToString(sum[:])
+}
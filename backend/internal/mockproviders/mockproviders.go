@@ -0,0 +1,158 @@
+// Package mockproviders answers the backend's calls to external providers in-process with
+// deterministic fake data, so the API and worker run offline for demos and end-to-end
+// frontend tests. It is enabled with MOCK_PROVIDERS=true.
+//
+// The fakes sit behind the provider clients rather than replacing them: every client sends
+// its requests through http.DefaultTransport, and Install puts a Transport in front of it
+// that answers requests for provider hosts with responses in each provider's own format.
+// Client parsing and error handling run exactly as they do against the real APIs.
+//
+// The fake world is the seed fixture set (internal/fixtures): the demo users' wallets hold
+// their fixture balances and show their fixture transactions, any other address holds a
+// fixed small balance, and coin prices move on a fixed weekly script. Bridge and swap
+// quotes are priced from the same script. Providers that aren't faked, such as the
+// exchanges, refuse requests instead of reaching the network.
+package mockproviders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+)
+
+// Transport serves provider hosts from the fakes and passes other requests to next
+type Transport struct {
+	next   http.RoundTripper
+	clock  clock.Clock
+	market *market
+	hosts  map[string]http.Handler
+
+	mu sync.Mutex
+	// mined is the block each transaction was first looked up at, so receipts keep their
+	// block and gain confirmations as the head moves
+	mined map[string]int64
+}
+
+// unmockedHosts are providers without a fake; their requests fail instead of going out
+var unmockedHosts = []string{
+	"api.binance.com",
+	"api.kraken.com",
+	"api.coinbase.com",
+	"beaconcha.in",
+	"blockstream.info",
+	"mempool.space",
+	"cosmos-rest.publicnode.com",
+	"osmosis-rest.publicnode.com",
+	"dashboard.alchemy.com",
+	"api.etherscan.io",
+	// Default news feed sources
+	"governance.aave.com",
+	"gov.uniswap.org",
+	"www.comp.xyz",
+	"research.lido.fi",
+}
+
+// NewTransport returns a Transport in front of next
+func NewTransport(next http.RoundTripper) *Transport {
+	t := &Transport{
+		next:   next,
+		clock:  clock.System,
+		market: newMarket(),
+		mined:  make(map[string]int64),
+	}
+	t.hosts = map[string]http.Handler{
+		"eth-mainnet.g.alchemy.com":     t.alchemy(1),
+		"opt-mainnet.g.alchemy.com":     t.alchemy(10),
+		"polygon-mainnet.g.alchemy.com": t.alchemy(137),
+		"arb-mainnet.g.alchemy.com":     t.alchemy(42161),
+		"rpc-amoy.polygon.technology":   t.alchemy(80002),
+		"api-amoy.polygonscan.com":      http.HandlerFunc(t.polygonscan),
+		"api.coingecko.com":             http.StripPrefix("/api/v3", t.coinGecko()),
+		"api.llama.fi":                  t.defiLlama(),
+		"li.quest":                      http.StripPrefix("/v1", t.lifi()),
+		"api.socket.tech":               http.StripPrefix("/v2", t.socket()),
+		"api.0x.org":                    t.zeroX(),
+		"api.1inch.io":                  t.oneInch(),
+		"api.hyperliquid.xyz":           t.hyperliquid(),
+		"arbitrum-api.gmxinfra.io":      t.gmx(),
+	}
+	for _, host := range unmockedHosts {
+		t.hosts[host] = http.HandlerFunc(unavailable)
+	}
+	return t
+}
+
+// Install routes provider requests made through http.DefaultTransport to the fakes
+func Install() {
+	http.DefaultTransport = NewTransport(http.DefaultTransport)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	handler, ok := t.hosts[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	w := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+	handler.ServeHTTP(w, req)
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		StatusCode:    w.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.header,
+		Body:          io.NopCloser(bytes.NewReader(w.body.Bytes())),
+		ContentLength: int64(w.body.Len()),
+		Request:       req,
+	}, nil
+}
+
+// responseRecorder collects a handler's response
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *responseRecorder) Header() http.Header { return w.header }
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// unavailable answers for providers without a fake
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+		"error": fmt.Sprintf("%s is not available in mock provider mode", r.URL.Hostname()),
+	})
+}
@@ -0,0 +1,192 @@
+package mockproviders
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/clients/bridge"
+	"github.com/defi-dashboard/backend/internal/clients/swap"
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+
+type stubTransport struct{ hosts []string }
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.hosts = append(s.hosts, req.URL.Host)
+	return &http.Response{StatusCode: http.StatusTeapot, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+// install puts a Transport with a fixed clock in front of a stub for the test
+func install(t *testing.T) (*Transport, *stubTransport) {
+	next := &stubTransport{}
+	transport := NewTransport(next)
+	transport.clock = clock.NewFake(testNow)
+
+	previous := http.DefaultTransport
+	http.DefaultTransport = transport
+	t.Cleanup(func() { http.DefaultTransport = previous })
+	return transport, next
+}
+
+func clientConfig(baseURL string) clients.ClientConfig {
+	return clients.ClientConfig{
+		BaseURL:    baseURL,
+		Timeout:    5 * time.Second,
+		RetryDelay: time.Millisecond,
+		RateLimit:  clients.RateLimitConfig{RequestsPerSecond: 100, BurstSize: 10},
+	}
+}
+
+func TestAlchemyServesFixtureBalances(t *testing.T) {
+	install(t)
+	client := blockchain.NewAlchemyClient("demo")
+	ctx := context.Background()
+
+	balances, err := client.GetTokenBalances(ctx, fixtures.AliceAddress, 1)
+	require.NoError(t, err)
+	held := make(map[string]string)
+	for _, balance := range balances {
+		held[balance.Token.Symbol] = balance.Balance
+	}
+	assert.Equal(t, map[string]string{
+		"ETH":   "4200000000000000000",
+		"USDC":  "12500000000",
+		"WBTC":  "15000000",
+		"stETH": "2000000000000000000",
+	}, held)
+
+	eth, err := client.GetETHBalance(ctx, fixtures.AliceAddress, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "4200000000000000000", eth.String())
+
+	// Addresses outside the fixtures hold the default balances
+	eth, err = client.GetETHBalance(ctx, "0x000000000000000000000000000000000000dEaD", 137)
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17)), eth)
+}
+
+func TestCoinGeckoPricesFollowTheScript(t *testing.T) {
+	transport, _ := install(t)
+	client := external.NewCoinGeckoClient("")
+	ctx := context.Background()
+
+	prices, err := client.GetTokenPrices(ctx, []string{"ethereum", "usd-coin", "not-a-coin"})
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.InDelta(t, 3150.42, prices["ethereum"].USD, 3150.42*0.1)
+	assert.InDelta(t, 1.0, prices["usd-coin"].USD, 0.001)
+
+	// The same moment always has the same price
+	again, err := client.GetTokenPrices(ctx, []string{"ethereum"})
+	require.NoError(t, err)
+	assert.Equal(t, prices["ethereum"], again["ethereum"])
+
+	// and it moves over time
+	transport.clock = clock.NewFake(testNow.Add(36 * time.Hour))
+	later, err := client.GetTokenPrices(ctx, []string{"ethereum"})
+	require.NoError(t, err)
+	assert.NotEqual(t, prices["ethereum"].USD, later["ethereum"].USD)
+
+	historical, err := client.GetHistoricalPrice(ctx, "ethereum", testNow.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Greater(t, historical, 0.0)
+
+	trending, err := client.GetTrendingCoins(ctx)
+	require.NoError(t, err)
+	require.Len(t, trending, 7)
+	assert.GreaterOrEqual(t, math.Abs(trending[0].PriceChange24h), math.Abs(trending[6].PriceChange24h))
+}
+
+func TestBridgeAndSwapQuotesArePricedFromTheMarket(t *testing.T) {
+	install(t)
+	ctx := context.Background()
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+	lifi := bridge.NewLiFiClient(clientConfig("https://li.quest/v1"))
+	quote, err := lifi.GetQuote(ctx, clients.QuoteRequest{
+		FromChainID: "1",
+		ToChainID:   "137",
+		FromToken:   usdc,
+		ToToken:     "0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174",
+		Amount:      "1000000000",
+		UserAddress: fixtures.AliceAddress,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "USDC", quote.ToToken.Symbol)
+	out, ok := new(big.Int).SetString(quote.ToAmount, 10)
+	require.True(t, ok)
+	assert.InDelta(t, 998.5e6, float64(out.Int64()), 1e6, "1,000 USDC less the bridge fee")
+
+	_, err = lifi.GetQuote(ctx, clients.QuoteRequest{
+		FromChainID: "1", ToChainID: "137", FromToken: usdc,
+		ToToken: "0x0000000000000000000000000000000000000bad", Amount: "1000000000",
+	})
+	assert.ErrorIs(t, err, clients.ErrNoRoutes)
+
+	zeroX := swap.NewZeroXClient(clientConfig("https://api.0x.org"))
+	swapQuote, err := zeroX.GetQuote(ctx, clients.QuoteRequest{
+		FromChainID: "1",
+		FromToken:   usdc,
+		ToToken:     "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		Amount:      "3150000000",
+	})
+	require.NoError(t, err)
+	weth, ok := new(big.Int).SetString(swapQuote.ToAmount, 10)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, float64(weth.Uint64())/1e18, 0.1)
+}
+
+func TestUnmockedHosts(t *testing.T) {
+	_, next := install(t)
+
+	resp, err := http.Get("https://api.binance.com/api/v3/ticker/price")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	// Hosts that aren't providers pass through
+	resp, err = http.Get("http://localhost:8200/v1/secret/data/keys")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	assert.Equal(t, []string{"localhost:8200"}, next.hosts)
+}
+
+func TestNobodyHoldsDerivatives(t *testing.T) {
+	install(t)
+	ctx := context.Background()
+
+	hyperliquid := external.NewHyperliquidClient()
+	positions, err := hyperliquid.GetPositions(ctx, fixtures.AliceAddress)
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+	funding, err := hyperliquid.GetFunding(ctx, fixtures.AliceAddress, testNow.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, funding)
+
+	gmx := external.NewGMXClient()
+	markets, err := gmx.GetMarkets(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, markets)
+	tokens, err := gmx.GetTokens(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	gmxPositions, err := blockchain.NewBlockchainService("demo", "").GetGMXPositions(ctx, fixtures.AliceAddress)
+	require.NoError(t, err)
+	assert.Empty(t, gmxPositions)
+}
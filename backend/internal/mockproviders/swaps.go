@@ -0,0 +1,176 @@
+package mockproviders
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+)
+
+// swapRouter is the contract fake swap transactions are sent to
+const swapRouter = "0xdef1c0ded9bec7f1a1670819833240f027b25eff"
+
+// zeroXChains maps 0x chain path names to chain IDs
+var zeroXChains = map[string]int{
+	"ethereum": 1,
+	"optimism": 10,
+	"polygon":  137,
+	"arbitrum": 42161,
+}
+
+// rate returns how many whole units of to one whole unit of from buys in a quote
+func rate(from, to fixtures.Token, amount, out string) string {
+	in, err := decimal.Parse(amount)
+	if err != nil || in.Sign() <= 0 {
+		return "0"
+	}
+	received, err := decimal.Parse(out)
+	if err != nil {
+		return "0"
+	}
+	whole := received.Mul(decimal.New(1, int32(to.Decimals))).Float64() / in.Mul(decimal.New(1, int32(from.Decimals))).Float64()
+	return strconv.FormatFloat(whole, 'g', 18, 64)
+}
+
+// zeroX serves the 0x swap API
+func (t *Transport) zeroX() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{chain}/swap/v1/quote", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		q := r.URL.Query()
+		chainID := zeroXChains[r.PathValue("chain")]
+		amount := q.Get("sellAmount")
+		from, to, out, ok := t.market.convert(chainID, q.Get("sellToken"), chainID, q.Get("buyToken"), amount, swapFee, now)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"code":   100,
+				"reason": "Validation Failed",
+				"validationErrors": []map[string]interface{}{{
+					"field": "sellAmount", "code": 1004, "reason": "INSUFFICIENT_ASSET_LIQUIDITY",
+				}},
+			})
+			return
+		}
+
+		gas := strconv.Itoa(quoteGas)
+		value := "0"
+		if from.Address == fixtures.NativeToken {
+			value = amount
+		}
+		price := rate(from, to, amount, out)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"chainId":              chainID,
+			"price":                price,
+			"guaranteedPrice":      price,
+			"estimatedPriceImpact": "0.03",
+			"to":                   swapRouter,
+			"data":                 "0x",
+			"value":                value,
+			"gas":                  gas,
+			"estimatedGas":         gas,
+			"gasPrice":             strconv.FormatInt(chains[chainID].GasPrice, 10),
+			"protocolFee":          "0",
+			"minimumProtocolFee":   "0",
+			"buyTokenAddress":      strings.ToLower(q.Get("buyToken")),
+			"sellTokenAddress":     strings.ToLower(q.Get("sellToken")),
+			"buyAmount":            out,
+			"sellAmount":           amount,
+			"sources": []map[string]string{
+				{"name": "Uniswap_V3", "proportion": "0.7"},
+				{"name": "Curve", "proportion": "0.3"},
+			},
+			"allowanceTarget":  swapRouter,
+			"decodedUniqueId":  requestID("0x", r.URL.Path, r.URL.RawQuery)[:12] + "-" + strconv.FormatInt(now.Unix(), 10),
+			"expectedSlippage": "0",
+		})
+	})
+
+	mux.HandleFunc("GET /{chain}/swap/v1/tokens", func(w http.ResponseWriter, r *http.Request) {
+		records := []map[string]interface{}{}
+		for _, token := range t.market.tokens[zeroXChains[r.PathValue("chain")]] {
+			address := strings.ToLower(token.Address)
+			if token.Address == fixtures.NativeToken {
+				address = strings.ToLower(nativePlaceholder)
+			}
+			records = append(records, map[string]interface{}{
+				"address":  address,
+				"chainId":  token.ChainID,
+				"name":     token.Name,
+				"symbol":   token.Symbol,
+				"decimals": token.Decimals,
+				"logoURI":  "",
+				"tags":     []string{},
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"records": records})
+	})
+
+	return mux
+}
+
+func oneInchToken(token fixtures.Token) map[string]interface{} {
+	address := strings.ToLower(token.Address)
+	if token.Address == fixtures.NativeToken {
+		address = strings.ToLower(nativePlaceholder)
+	}
+	return map[string]interface{}{
+		"symbol":   token.Symbol,
+		"name":     token.Name,
+		"address":  address,
+		"decimals": token.Decimals,
+		"logoURI":  "",
+	}
+}
+
+// oneInch serves the 1inch aggregation API
+func (t *Transport) oneInch() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v5.0/{chain}/quote", func(w http.ResponseWriter, r *http.Request) {
+		now := t.clock.Now()
+		q := r.URL.Query()
+		chainID, _ := strconv.Atoi(r.PathValue("chain"))
+		amount := q.Get("amount")
+		from, to, out, ok := t.market.convert(chainID, q.Get("fromTokenAddress"), chainID, q.Get("toTokenAddress"), amount, swapFee, now)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"statusCode":  400,
+				"error":       "Bad Request",
+				"description": "insufficient liquidity",
+			})
+			return
+		}
+
+		fromAddress, toAddress := oneInchToken(from)["address"], oneInchToken(to)["address"]
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"fromToken":       oneInchToken(from),
+			"toToken":         oneInchToken(to),
+			"fromTokenAmount": amount,
+			"toTokenAmount":   out,
+			"protocols": [][][]map[string]interface{}{{{
+				{"name": "UNISWAP_V3", "part": 80, "fromTokenAddress": fromAddress, "toTokenAddress": toAddress},
+				{"name": "CURVE_V2", "part": 20, "fromTokenAddress": fromAddress, "toTokenAddress": toAddress},
+			}}},
+			"estimatedGas": quoteGas,
+		})
+	})
+
+	mux.HandleFunc("GET /v5.0/{chain}/tokens", func(w http.ResponseWriter, r *http.Request) {
+		chainID, _ := strconv.Atoi(r.PathValue("chain"))
+		tokens := make(map[string]interface{})
+		for _, token := range t.market.tokens[chainID] {
+			info := oneInchToken(token)
+			tokens[info["address"].(string)] = info
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+	})
+
+	mux.HandleFunc("GET /v5.0/{chain}/healthcheck", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "OK"})
+	})
+
+	return mux
+}
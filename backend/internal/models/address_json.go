@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/defi-dashboard/backend/pkg/address"
+)
+
+// Addresses are stored lowercase and written out with their EIP-55 checksum
+
+// MarshalJSON writes the user's address checksummed
+func (u User) MarshalJSON() ([]byte, error) {
+	type alias User
+	a := alias(u)
+	a.Address = address.Checksum(a.Address)
+	return json.Marshal(a)
+}
+
+// MarshalJSON writes the wallet's address checksummed
+func (w Wallet) MarshalJSON() ([]byte, error) {
+	type alias Wallet
+	a := alias(w)
+	a.Address = address.Checksum(a.Address)
+	return json.Marshal(a)
+}
+
+// MarshalJSON writes the token's contract address checksummed
+func (t Token) MarshalJSON() ([]byte, error) {
+	type alias Token
+	a := alias(t)
+	a.Address = address.Checksum(a.Address)
+	return json.Marshal(a)
+}
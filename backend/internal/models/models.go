@@ -1,8 +1,13 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/defi-dashboard/backend/pkg/decimal"
 	"github.com/google/uuid"
 )
 
@@ -34,17 +39,283 @@ type Wallet struct {
 	UserID    uuid.UUID `json:"user_id"`
 	Address   string    `json:"address"`
 	ChainID   int       `json:"chain_id"`
+	// Chain identifies non-EVM chains, whose wallets have a ChainID of 0
+	Chain     *ChainRef `json:"chain,omitempty"`
 	Label     *string   `json:"label,omitempty"`
+	// GroupID is the wallet group the owner filed the wallet under, if any
+	GroupID   *uuid.UUID `json:"group_id,omitempty"`
+	// Visibility is WalletVisibilityPrivate for wallets kept out of shared views
+	Visibility string   `json:"visibility"`
+	// AccountType is what the address holds on chain, one of the WalletAccount* values;
+	// nil until detected
+	AccountType *string `json:"account_type,omitempty"`
 	IsPrimary bool      `json:"is_primary"`
+	// IsTestnet keeps the wallet out of mainnet totals unless testnets are included
+	IsTestnet bool      `json:"is_testnet"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Wallet visibilities. Private wallets are left out of shared links and of views
+// aggregating several users' wallets; their owner still sees them everywhere.
+const (
+	WalletVisibilityVisible = "visible"
+	WalletVisibilityPrivate = "private"
+)
+
+// Wallet account types, detected from the code at the wallet's address
+const (
+	// WalletAccountEOA is a plain key-controlled account, including one delegating to a
+	// contract under EIP-7702
+	WalletAccountEOA = "eoa"
+	// WalletAccountSafe is a Safe multisig
+	WalletAccountSafe = "safe"
+	// WalletAccountERC4337 is a smart account driven through an ERC-4337 entry point
+	WalletAccountERC4337 = "erc4337"
+	// WalletAccountContract is any other contract
+	WalletAccountContract = "contract"
+)
+
+// IsContractAccount reports whether the wallet is a contract. Contracts can't sign, so
+// their signatures are checked with EIP-1271 rather than by recovering a signer.
+func (w *Wallet) IsContractAccount() bool {
+	if w.AccountType == nil {
+		return false
+	}
+	switch *w.AccountType {
+	case WalletAccountSafe, WalletAccountERC4337, WalletAccountContract:
+		return true
+	}
+	return false
+}
+
+// UpdateWalletVisibilityRequest makes a wallet private or visible again
+type UpdateWalletVisibilityRequest struct {
+	Visibility string `json:"visibility" validate:"required,oneof=visible private"`
+}
+
+// SharedWallets returns the wallets that may appear in views shown to other people.
+// Shared links and cross-user aggregations must go through it.
+func SharedWallets(wallets []*Wallet) []*Wallet {
+	var shared []*Wallet
+	for _, wallet := range wallets {
+		if wallet.Visibility != WalletVisibilityPrivate {
+			shared = append(shared, wallet)
+		}
+	}
+	return shared
+}
+
+// IsEVM reports whether the wallet lives on an EVM chain identified by ChainID
+func (w *Wallet) IsEVM() bool {
+	return w.Chain == nil || w.Chain.Namespace == ChainNamespaceEVM
+}
+
+// Chain namespaces, as defined by CAIP-2
+const (
+	ChainNamespaceEVM     = "eip155"
+	ChainNamespaceCosmos  = "cosmos"
+	ChainNamespaceBitcoin = "bip122"
+)
+
+// BitcoinChain is Bitcoin mainnet, referenced by its genesis block hash prefix
+var BitcoinChain = ChainRef{Namespace: ChainNamespaceBitcoin, Reference: "000000000019d6689c085ae165831e93"}
+
+// BTCTokenAddress identifies bitcoin in the tokens table, under chain ID 0. It's only a
+// storage key for tables keyed by EVM chain ID and address, such as prices and PnL: a
+// Token with it resolves to its Asset on BitcoinChain, and price alerts on BTC target it.
+const BTCTokenAddress = "btc"
+
+// ChainRef identifies a chain in any ecosystem as a CAIP-2 namespace and reference,
+// e.g. eip155:1 or cosmos:cosmoshub-4. It is encoded as that string in JSON.
+type ChainRef struct {
+	Namespace string
+	Reference string
+}
+
+// EVMChain returns the reference of an EVM chain ID
+func EVMChain(chainID int) ChainRef {
+	return ChainRef{Namespace: ChainNamespaceEVM, Reference: strconv.Itoa(chainID)}
+}
+
+// ParseChainRef parses a namespace:reference chain identifier
+func ParseChainRef(s string) (ChainRef, error) {
+	namespace, reference, ok := strings.Cut(s, ":")
+	if !ok || namespace == "" || reference == "" {
+		return ChainRef{}, fmt.Errorf("invalid chain reference %q", s)
+	}
+	return ChainRef{Namespace: namespace, Reference: reference}, nil
+}
+
+func (r ChainRef) String() string {
+	return r.Namespace + ":" + r.Reference
+}
+
+// EVMChainID returns the integer chain ID of an EVM chain
+func (r ChainRef) EVMChainID() (int, bool) {
+	if r.Namespace != ChainNamespaceEVM {
+		return 0, false
+	}
+	id, err := strconv.Atoi(r.Reference)
+	return id, err == nil
+}
+
+func (r ChainRef) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *ChainRef) UnmarshalText(text []byte) error {
+	ref, err := ParseChainRef(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+// ChainNamespaceExchange identifies a centralized exchange, holding off-chain assets, in
+// place of a chain. It is not a registered CAIP-2 namespace.
+const ChainNamespaceExchange = "cex"
+
+// Asset classes
+const (
+	AssetClassNative   = "native"   // A chain's native coin (ETH, BTC, ATOM)
+	AssetClassFungible = "fungible" // A token contract or denom
+	AssetClassNFT      = "nft"
+	AssetClassOffChain = "offchain" // A custodial balance, e.g. on an exchange
+)
+
+// Asset namespaces, as defined by CAIP-19, plus symbol for off-chain assets
+const (
+	AssetNamespaceSLIP44  = "slip44"
+	AssetNamespaceERC20   = "erc20"
+	AssetNamespaceERC721  = "erc721"
+	AssetNamespaceERC1155 = "erc1155"
+	AssetNamespaceDenom   = "denom"
+	AssetNamespaceSymbol  = "symbol"
+)
+
+// evmZeroAddress stands for the native coin in EVM token rows
+const evmZeroAddress = "0x0000000000000000000000000000000000000000"
+
+// nativeCoinTypes are the SLIP-44 coin types of native coins other than ether, by chain
+var nativeCoinTypes = map[string]int{
+	"eip155:137":                          966,
+	"eip155:80002":                        966,
+	BitcoinChain.String():                 0,
+	ChainNamespaceCosmos + ":cosmoshub-4": 118,
+	ChainNamespaceCosmos + ":osmosis-1":   118,
+}
+
+// cosmosNativeDenoms are the native coin denoms of supported Cosmos SDK chains
+var cosmosNativeDenoms = map[string]string{
+	"cosmoshub-4": "uatom",
+	"osmosis-1":   "uosmo",
+}
+
+// AssetRef identifies an asset on any chain or exchange as a CAIP-19 style
+// chain/namespace:reference, with a token ID for NFTs, e.g. eip155:1/erc20:0xa0b8...,
+// bip122:000000000019d6689c085ae165831e93/slip44:0 or cex:kraken/symbol:BTC. It is
+// encoded as that string in JSON.
+type AssetRef struct {
+	Chain     ChainRef
+	Namespace string
+	Reference string
+	TokenID   string // Set for NFTs
+}
+
+// ParseAssetRef parses a chain/namespace:reference[/token_id] asset identifier
+func ParseAssetRef(s string) (AssetRef, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return AssetRef{}, fmt.Errorf("invalid asset reference %q", s)
+	}
+	chain, err := ParseChainRef(parts[0])
+	if err != nil {
+		return AssetRef{}, fmt.Errorf("invalid asset reference %q: %w", s, err)
+	}
+	namespace, reference, ok := strings.Cut(parts[1], ":")
+	if !ok || namespace == "" || reference == "" {
+		return AssetRef{}, fmt.Errorf("invalid asset reference %q", s)
+	}
+
+	ref := AssetRef{Chain: chain, Namespace: namespace, Reference: reference}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return AssetRef{}, fmt.Errorf("invalid asset reference %q", s)
+		}
+		ref.TokenID = parts[2]
+	}
+	return ref, nil
+}
+
+func (r AssetRef) String() string {
+	s := r.Chain.String() + "/" + r.Namespace + ":" + r.Reference
+	if r.TokenID != "" {
+		s += "/" + r.TokenID
+	}
+	return s
+}
+
+func (r AssetRef) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *AssetRef) UnmarshalText(text []byte) error {
+	ref, err := ParseAssetRef(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+// Asset is anything a user can hold, on any chain or off-chain: native coins, tokens,
+// NFTs and exchange balances
+type Asset struct {
+	ID       AssetRef `json:"id"`
+	Class    string   `json:"class"`
+	Symbol   string   `json:"symbol,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Decimals int      `json:"decimals"`
+	// Contract is the token contract or denom; nil for native coins and off-chain assets
+	Contract *string `json:"contract,omitempty"`
+}
+
+// NFTAsset returns the asset of one NFT
+func NFTAsset(chainID int, contract, tokenID, standard string) Asset {
+	namespace := AssetNamespaceERC721
+	if standard == "erc1155" {
+		namespace = AssetNamespaceERC1155
+	}
+	contract = strings.ToLower(contract)
+	return Asset{
+		ID:       AssetRef{Chain: EVMChain(chainID), Namespace: namespace, Reference: contract, TokenID: tokenID},
+		Class:    AssetClassNFT,
+		Contract: &contract,
+	}
+}
+
+// ExchangeAsset returns the asset of a balance held on an exchange
+func ExchangeAsset(exchange, symbol string) Asset {
+	symbol = strings.ToUpper(symbol)
+	return Asset{
+		ID: AssetRef{
+			Chain:     ChainRef{Namespace: ChainNamespaceExchange, Reference: exchange},
+			Namespace: AssetNamespaceSymbol,
+			Reference: symbol,
+		},
+		Class:  AssetClassOffChain,
+		Symbol: symbol,
+	}
+}
+
 // Token represents a cryptocurrency token
 type Token struct {
 	ID            uuid.UUID `json:"id"`
 	Address       string    `json:"address"`
 	ChainID       int       `json:"chain_id"`
+	Chain         *ChainRef `json:"chain,omitempty"` // Set for tokens on non-EVM chains
 	Symbol        string    `json:"symbol"`
 	Name          string    `json:"name"`
 	Decimals      int       `json:"decimals"`
@@ -56,6 +327,178 @@ type Token struct {
 	LastUpdated   *time.Time `json:"last_updated,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	Metadata      *TokenMetadata `json:"metadata,omitempty"`
+	Holders       *TokenHolderStats `json:"holders,omitempty"`
+	// UserSupplyPct is the share of the supply the requesting user's wallets hold
+	UserSupplyPct *float64 `json:"user_supply_pct,omitempty"`
+	Contract      *ContractInfo `json:"contract,omitempty"`
+}
+
+// TokenListSyncResult counts the tokens a token list sync added and those whose gaps it
+// filled, with the addresses of both by chain
+type TokenListSyncResult struct {
+	Inserted int
+	Filled   int
+	Changed  map[int][]string
+}
+
+// ChainRef returns the chain the token lives on
+func (t *Token) ChainRef() ChainRef {
+	if t.Chain != nil {
+		return *t.Chain
+	}
+	if t.Address == BTCTokenAddress && t.ChainID == 0 {
+		return BitcoinChain
+	}
+	return EVMChain(t.ChainID)
+}
+
+// IsNative reports whether the token is its chain's native coin
+func (t *Token) IsNative() bool {
+	chain := t.ChainRef()
+	switch chain.Namespace {
+	case ChainNamespaceEVM:
+		return strings.EqualFold(t.Address, evmZeroAddress)
+	case ChainNamespaceBitcoin:
+		return true
+	case ChainNamespaceCosmos:
+		return t.Address == cosmosNativeDenoms[chain.Reference]
+	default:
+		return false
+	}
+}
+
+// Asset maps the token into the chain-agnostic asset model
+func (t *Token) Asset() Asset {
+	chain := t.ChainRef()
+	asset := Asset{
+		Class:    AssetClassFungible,
+		Symbol:   t.Symbol,
+		Name:     t.Name,
+		Decimals: t.Decimals,
+	}
+
+	if t.IsNative() {
+		coinType, ok := nativeCoinTypes[chain.String()]
+		if !ok {
+			coinType = 60 // Ether, native on Ethereum and its rollups
+		}
+		asset.ID = AssetRef{Chain: chain, Namespace: AssetNamespaceSLIP44, Reference: strconv.Itoa(coinType)}
+		asset.Class = AssetClassNative
+		return asset
+	}
+
+	contract := t.Address
+	switch chain.Namespace {
+	case ChainNamespaceEVM:
+		contract = strings.ToLower(contract)
+		asset.ID = AssetRef{Chain: chain, Namespace: AssetNamespaceERC20, Reference: contract}
+	default:
+		// Denoms such as ibc/27394F... contain slashes, which references can't
+		asset.ID = AssetRef{Chain: chain, Namespace: AssetNamespaceDenom, Reference: strings.ReplaceAll(contract, "/", "%2F")}
+	}
+	asset.Contract = &contract
+	return asset
+}
+
+// TokenMetadata is CoinGecko enrichment for a token
+type TokenMetadata struct {
+	TokenID           uuid.UUID `json:"token_id"`
+	CoinGeckoID       *string   `json:"coingecko_id,omitempty"`
+	Categories        []string  `json:"categories"`
+	Description       *string   `json:"description,omitempty"`
+	Website           *string   `json:"website,omitempty"`
+	Twitter           *string   `json:"twitter,omitempty"`
+	Telegram          *string   `json:"telegram,omitempty"`
+	Discord           *string   `json:"discord,omitempty"`
+	GitHub            *string   `json:"github,omitempty"`
+	ImageURL          *string   `json:"image_url,omitempty"`
+	CirculatingSupply *float64  `json:"circulating_supply,omitempty"`
+	NotFound          bool      `json:"-"`
+	FetchedAt         time.Time `json:"fetched_at"`
+}
+
+// Sector returns the token's primary category, used to group holdings by sector
+func (m *TokenMetadata) Sector() string {
+	if m == nil || len(m.Categories) == 0 {
+		return SectorUncategorized
+	}
+	return m.Categories[0]
+}
+
+// SectorUncategorized groups holdings without CoinGecko categories
+const SectorUncategorized = "Uncategorized"
+
+// Concentration risk ratings of a token's holder distribution
+const (
+	ConcentrationRiskLow    = "low"
+	ConcentrationRiskMedium = "medium"
+	ConcentrationRiskHigh   = "high"
+)
+
+// TokenHolderStats is how concentrated a token's supply is among its largest holders
+type TokenHolderStats struct {
+	TokenID     uuid.UUID          `json:"token_id"`
+	TotalSupply string             `json:"total_supply"`
+	TopHolders  []TokenHolderShare `json:"top_holders"`
+	// Top10SharePct is the share of the supply the ten largest holders hold
+	Top10SharePct     float64   `json:"top10_share_pct"`
+	ConcentrationRisk string    `json:"concentration_risk"`
+	Source            string    `json:"source"`
+	NotFound          bool      `json:"-"`
+	FetchedAt         time.Time `json:"fetched_at"`
+}
+
+// Contract risk flags lowering a contract's security score
+const (
+	ContractFlagNotContract              = "not_contract"
+	ContractFlagUnverified               = "unverified"
+	ContractFlagProxy                    = "proxy"
+	ContractFlagUnverifiedImplementation = "unverified_implementation"
+	ContractFlagNew                      = "new_contract"
+)
+
+// ContractInfo is what a chain explorer knows of an address tokens or spenders live at
+type ContractInfo struct {
+	ChainID    int     `json:"chain_id"`
+	Address    string  `json:"address"`
+	IsContract bool    `json:"is_contract"`
+	Verified   bool    `json:"verified"`
+	Name       *string `json:"name,omitempty"`
+	IsProxy    bool    `json:"is_proxy"`
+	// Implementation is the contract a proxy delegates to, and ImplementationVerified
+	// whether its source was verified
+	Implementation         *string    `json:"implementation,omitempty"`
+	ImplementationVerified *bool      `json:"implementation_verified,omitempty"`
+	Creator                *string    `json:"creator,omitempty"`
+	CreationTxHash         *string    `json:"creation_tx_hash,omitempty"`
+	DeployedAt             *time.Time `json:"deployed_at,omitempty"`
+	// SecurityScore runs from 0 to 100, lowered by each of RiskFlags
+	SecurityScore int       `json:"security_score"`
+	RiskFlags     []string  `json:"risk_flags"`
+	Source        string    `json:"source"`
+	FetchedAt     time.Time `json:"fetched_at"`
+}
+
+// ContractRef is a contract address on a chain
+type ContractRef struct {
+	ChainID int
+	Address string
+}
+
+// TokenHolderShare is one of a token's largest holders
+type TokenHolderShare struct {
+	Address  string  `json:"address"`
+	Balance  string  `json:"balance"`
+	SharePct float64 `json:"share_pct"`
+}
+
+// SectorAllocation is the share of a portfolio's value in one sector
+type SectorAllocation struct {
+	Sector     string   `json:"sector"`
+	ValueUSD   float64  `json:"value_usd"`
+	Percentage float64  `json:"percentage"`
+	Tokens     []string `json:"tokens"`
 }
 
 // Balance represents a token balance for a wallet
@@ -64,13 +507,37 @@ type Balance struct {
 	WalletID    uuid.UUID `json:"wallet_id"`
 	TokenID     uuid.UUID `json:"token_id"`
 	Token       *Token    `json:"token,omitempty"`
+	Asset       *Asset    `json:"asset,omitempty"` // Token in the chain-agnostic asset model
 	Balance     string    `json:"balance"`
 	BalanceUSD  *float64  `json:"balance_usd,omitempty"`
 	BlockNumber *int64    `json:"block_number,omitempty"`
+	BlockHash   *string   `json:"block_hash,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// BalanceChange is a difference a balance refresh found between a wallet's stored
+// balance of a token and its balance on chain. Balances are in the token's base units;
+// a positive Delta was received.
+type BalanceChange struct {
+	ID              uuid.UUID `json:"id"`
+	WalletID        uuid.UUID `json:"wallet_id"`
+	TokenID         uuid.UUID `json:"token_id"`
+	Token           *Token    `json:"token,omitempty"`
+	PreviousBalance string    `json:"previous_balance"`
+	NewBalance      string    `json:"new_balance"`
+	Delta           string    `json:"delta"`
+	DeltaUSD        *float64  `json:"delta_usd,omitempty"`
+	BlockNumber     *int64    `json:"block_number,omitempty"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// Balance change alert directions; alerts without one fire on changes either way
+const (
+	BalanceChangeDirectionIn  = "in"
+	BalanceChangeDirectionOut = "out"
+)
+
 // Transaction represents a blockchain transaction
 type Transaction struct {
 	ID          uuid.UUID              `json:"id"`
@@ -83,12 +550,342 @@ type Transaction struct {
 	GasPrice    *string                `json:"gas_price,omitempty"`
 	GasFeeUSD   *float64               `json:"gas_fee_usd,omitempty"`
 	BlockNumber *int64                 `json:"block_number,omitempty"`
+	BlockHash   *string                `json:"block_hash,omitempty"`
 	Timestamp   time.Time              `json:"timestamp"`
 	Status      string                 `json:"status"`
 	Type        string                 `json:"type"`
+	Source      string                 `json:"source"` // 'onchain' or 'manual'
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
+
+	// Confirmations counts blocks on top of the transaction's block while it is pending;
+	// RequiredConfirmations is the chain's finality threshold
+	Confirmations         int64 `json:"confirmations"`
+	RequiredConfirmations int64 `json:"required_confirmations,omitempty"`
+
+	// Annotation is the requesting user's category, tags and notes, if any
+	Annotation *TransactionAnnotation `json:"annotation,omitempty"`
+
+	// PoisoningLookalikeOf is set on zero-value transfers suspected of address poisoning
+	// to the address their counterparty imitates. Analytics leave them out.
+	PoisoningLookalikeOf *string `json:"poisoning_lookalike_of,omitempty"`
+}
+
+// TransactionAnnotation is a user's own metadata on a transaction. Its category, when
+// set, overrides the PnL engine's income classification.
+type TransactionAnnotation struct {
+	ID              uuid.UUID `json:"id"`
+	UserID          uuid.UUID `json:"user_id"`
+	TransactionHash string    `json:"transaction_hash"`
+	Category        *string   `json:"category,omitempty"` // an income category or "not_income"
+	Tags            []string  `json:"tags"`
+	Notes           *string   `json:"notes,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// UpdateTransactionAnnotationRequest replaces a transaction's annotation; omitted fields
+// are cleared
+type UpdateTransactionAnnotationRequest struct {
+	Category *string  `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Notes    *string  `json:"notes,omitempty"`
+}
+
+// ProtocolInteraction summarizes a wallet's transactions with one protocol's contracts
+// on one chain. Volume is the native value transferred, in base units.
+type ProtocolInteraction struct {
+	Protocol        string    `json:"protocol"`
+	Name            string    `json:"name"`
+	ChainID         int       `json:"chain_id"`
+	TxCount         int       `json:"tx_count"`
+	Volume          string    `json:"volume"`
+	LastInteraction time.Time `json:"last_interaction"`
+}
+
+// GasUsage is the gas a wallet's transactions of one type paid for on one chain
+type GasUsage struct {
+	ChainID int     `json:"chain_id"`
+	Type    string  `json:"type"`
+	TxCount int     `json:"tx_count"`
+	GasUsed int64   `json:"gas_used"`
+	FeeUSD  float64 `json:"fee_usd"`
+}
+
+// Transaction status constants
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusConfirmed = "confirmed"
+	TransactionStatusFailed    = "failed"
+	TransactionStatusDropped   = "dropped"
+)
+
+// Wallet backfill statuses
+const (
+	BackfillStatusPending   = "pending"
+	BackfillStatusRunning   = "running"
+	BackfillStatusCompleted = "completed"
+	BackfillStatusFailed    = "failed"
+)
+
+// WalletBackfill is the import of a wallet's transaction history, from genesis up to
+// the chain head when it started, in chunks of blocks
+type WalletBackfill struct {
+	ID            uuid.UUID `json:"id"`
+	WalletID      uuid.UUID `json:"wallet_id"`
+	UserID        uuid.UUID `json:"-"`
+	WalletAddress string    `json:"-"`
+	ChainID       int       `json:"chain_id"`
+	Status        string    `json:"status"`
+	TargetBlock   *int64    `json:"target_block,omitempty"`
+	// NextBlock is the first block not yet imported
+	NextBlock         int64      `json:"next_block"`
+	TransactionsFound int        `json:"transactions_found"`
+	Attempts          int        `json:"-"`
+	Error             *string    `json:"error,omitempty"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Progress is the percentage of blocks imported
+	Progress float64 `json:"progress"`
+}
+
+// ImportedPercent returns the percentage of the backfill's blocks already imported
+func (b *WalletBackfill) ImportedPercent() float64 {
+	if b.Status == BackfillStatusCompleted {
+		return 100
+	}
+	if b.TargetBlock == nil {
+		return 0
+	}
+	percent := float64(b.NextBlock) / float64(*b.TargetBlock+1) * 100
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// Budget metrics, each tracked over the calendar month
+const (
+	// BudgetMetricGasSpend is the gas the user's wallets paid for transactions they sent
+	BudgetMetricGasSpend = "gas_spend"
+	// BudgetMetricTradingVolume is the value of tokens the user's wallets sold in swaps
+	BudgetMetricTradingVolume = "trading_volume"
+	// BudgetMetricRealizedLoss is the user's net realized loss, zero when in profit
+	BudgetMetricRealizedLoss = "realized_loss"
+)
+
+// Budget is a user's soft monthly limit on spending of one kind. Going over it fires
+// the user's budget_exceeded alerts; nothing is blocked.
+type Budget struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Metric    string    `json:"metric"`
+	LimitUSD  float64   `json:"limit_usd"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BudgetProgress is the spending against a budget over the month so far
+type BudgetProgress struct {
+	*Budget
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	SpentUSD    float64   `json:"spent_usd"`
+	// Percent is the share of the limit spent, over 100 once exceeded
+	Percent  float64 `json:"percent"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// SetBudgetRequest sets the limit of the user's budget for a metric
+type SetBudgetRequest struct {
+	LimitUSD float64 `json:"limit_usd"`
+}
+
+// Wallet label suggestion statuses
+const (
+	WalletLabelSuggested = "suggested"
+	WalletLabelAccepted  = "accepted"
+	WalletLabelDismissed = "dismissed"
+)
+
+// WalletLabelSuggestion is a label a wallet's on-chain history suggests, such as
+// "Uniswap LP" for a wallet that often provides liquidity. Interactions counts the
+// transactions behind it; accepted labels are the wallet's tags.
+type WalletLabelSuggestion struct {
+	WalletID     uuid.UUID `json:"wallet_id"`
+	Label        string    `json:"label"`
+	Interactions int       `json:"interactions"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PoisonedCounterparty is a lookalike address that sent a wallet zero-value transfers to
+// get into its history, imitating LookalikeOf, an address the wallet really deals with
+type PoisonedCounterparty struct {
+	WalletID         uuid.UUID `json:"wallet_id"`
+	Address          string    `json:"address"`
+	LookalikeOf      string    `json:"lookalike_of"`
+	TransactionCount int       `json:"transaction_count"`
+	FirstSeenAt      time.Time `json:"first_seen_at"`
+	LastSeenAt       time.Time `json:"last_seen_at"`
+}
+
+// UpdateWalletLabelSuggestionRequest accepts or dismisses a suggested label
+type UpdateWalletLabelSuggestionRequest struct {
+	Label  string `json:"label"`
+	Status string `json:"status"`
+}
+
+// Balance refresh statuses
+const (
+	BalanceRefreshPending   = "pending"
+	BalanceRefreshRunning   = "running"
+	BalanceRefreshCompleted = "completed"
+)
+
+// BalanceRefresh re-fetches the balances of a set of EVM wallets on an admin's request,
+// such as after adding a chain or fixing a balance bug: the wallets listed, or every
+// wallet, on one chain if ChainID is set
+type BalanceRefresh struct {
+	ID          uuid.UUID   `json:"id"`
+	RequestedBy *uuid.UUID  `json:"requested_by,omitempty"`
+	WalletIDs   []uuid.UUID `json:"wallet_ids,omitempty"`
+	ChainID     *int        `json:"chain_id,omitempty"`
+	Status      string      `json:"status"`
+	// Total is how many wallets the refresh covers; Refreshed and Failed count those done
+	Total     int `json:"total"`
+	Refreshed int `json:"refreshed"`
+	Failed    int `json:"failed"`
+	// Cursor is the last wallet done, by ID
+	Cursor      *uuid.UUID `json:"-"`
+	LastError   *string    `json:"last_error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Progress is the percentage of wallets done
+	Progress float64 `json:"progress"`
+}
+
+// DonePercent returns the percentage of the refresh's wallets already done
+func (r *BalanceRefresh) DonePercent() float64 {
+	if r.Status == BalanceRefreshCompleted || r.Total == 0 {
+		return 100
+	}
+	percent := float64(r.Refreshed+r.Failed) / float64(r.Total) * 100
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// CreateBalanceRefreshRequest selects the wallets to refresh: those listed, or all of
+// them when none are, optionally only on one chain
+type CreateBalanceRefreshRequest struct {
+	WalletIDs []uuid.UUID `json:"wallet_ids,omitempty"`
+	ChainID   *int        `json:"chain_id,omitempty"`
+}
+
+// Wallet removal retentions: a purge deletes everything kept for the wallet, while
+// keeping history keeps its transactions, PnL lots and valuations for past reports
+const (
+	WalletRemovalPurge       = "purge"
+	WalletRemovalKeepHistory = "keep_history"
+)
+
+// Wallet removal statuses
+const (
+	WalletRemovalPending   = "pending"
+	WalletRemovalRunning   = "running"
+	WalletRemovalCompleted = "completed"
+	WalletRemovalFailed    = "failed"
+)
+
+// Wallet removal steps, each clearing one kind of data kept for the wallet
+const (
+	WalletRemovalStepAlerts       = "alerts"
+	WalletRemovalStepBalances     = "balances"
+	WalletRemovalStepAllowances   = "allowances"
+	WalletRemovalStepPositions    = "positions"
+	WalletRemovalStepNFTs         = "nft_transfers"
+	WalletRemovalStepSnapshots    = "snapshots"
+	WalletRemovalStepBackfill     = "backfill"
+	WalletRemovalStepLabels       = "label_suggestions"
+	WalletRemovalStepLots         = "pnl_lots"
+	WalletRemovalStepTransactions = "transactions"
+	WalletRemovalStepWallet       = "wallet"
+)
+
+// walletRemovalSteps are the steps every removal runs; a purge goes on to delete the
+// wallet's history and finally the wallet itself
+var (
+	walletRemovalSteps = []string{
+		WalletRemovalStepAlerts, WalletRemovalStepBalances, WalletRemovalStepAllowances,
+		WalletRemovalStepPositions, WalletRemovalStepNFTs, WalletRemovalStepSnapshots,
+		WalletRemovalStepBackfill, WalletRemovalStepLabels,
+	}
+	walletPurgeSteps = append(append([]string{}, walletRemovalSteps...),
+		WalletRemovalStepLots, WalletRemovalStepTransactions, WalletRemovalStepWallet)
+)
+
+// WalletRemovalSteps returns the steps a removal with the retention runs, in order
+func WalletRemovalSteps(retention string) []string {
+	if retention == WalletRemovalPurge {
+		return walletPurgeSteps
+	}
+	return walletRemovalSteps
+}
+
+// WalletRemoval clears the data kept for a wallet its owner removed. The wallet is
+// hidden as soon as it's removed; the worker then runs the removal's steps in order.
+type WalletRemoval struct {
+	ID        uuid.UUID `json:"id"`
+	WalletID  uuid.UUID `json:"wallet_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Address   string    `json:"address"`
+	ChainID   int       `json:"chain_id"`
+	Retention string    `json:"retention"`
+	Status    string    `json:"status"`
+	// StepsTotal is how many steps the removal runs; StepsDone counts those done
+	StepsTotal int `json:"steps_total"`
+	StepsDone  int `json:"steps_done"`
+	// RowsDeleted counts the rows the steps done deleted or, for alerts kept, disabled
+	RowsDeleted int64 `json:"rows_deleted"`
+	// Attempts counts the failed attempts at the current step
+	Attempts    int        `json:"-"`
+	LastError   *string    `json:"last_error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// CurrentStep is the step running next, empty once the removal is done
+	CurrentStep string `json:"current_step,omitempty"`
+	// Progress is the percentage of steps done
+	Progress float64 `json:"progress"`
+}
+
+// NextStep returns the step the removal runs next, or "" when all are done
+func (r *WalletRemoval) NextStep() string {
+	steps := WalletRemovalSteps(r.Retention)
+	if r.StepsDone >= len(steps) || r.Status == WalletRemovalCompleted || r.Status == WalletRemovalFailed {
+		return ""
+	}
+	return steps[r.StepsDone]
+}
+
+// DonePercent returns the percentage of the removal's steps already done
+func (r *WalletRemoval) DonePercent() float64 {
+	if r.Status == WalletRemovalCompleted || r.StepsTotal == 0 {
+		return 100
+	}
+	return float64(r.StepsDone) / float64(r.StepsTotal) * 100
 }
 
 // TokenAllowance represents a token approval/allowance
@@ -105,6 +902,8 @@ type TokenAllowance struct {
 	BlockNumber     *int64     `json:"block_number,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
+	// VerifiedAt is when the allowance was last read on chain, nil if it never was
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
 }
 
 // Protocol represents a DeFi protocol
@@ -124,88 +923,854 @@ type Protocol struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
-// YieldPool represents a yield farming pool with enhanced information
-type YieldPool struct {
-	ID             uuid.UUID      `json:"id"`
-	PoolID         string         `json:"pool_id"`
-	ProtocolID     *uuid.UUID     `json:"protocol_id,omitempty"`
-	Protocol       *Protocol      `json:"protocol,omitempty"`
-	PoolName       string         `json:"pool_name"`
-	ChainID        *int           `json:"chain_id,omitempty"`
-	Chain          string         `json:"chain"`
-	PoolAddress    *string        `json:"pool_address,omitempty"`
-	Symbol         string         `json:"symbol"`
-	TokenAddresses []string       `json:"token_addresses,omitempty"`
-	
-	// Financial metrics
-	TVLUSD       *float64 `json:"tvl_usd,omitempty"`
-	APY          *float64 `json:"apy,omitempty"`
-	APYBase      *float64 `json:"apy_base,omitempty"`
-	APYReward    *float64 `json:"apy_reward,omitempty"`
-	FeesAPR      *float64 `json:"fees_apr,omitempty"`
-	IL7D         *float64 `json:"il_7d,omitempty"` // Impermanent loss 7 days
-	
-	// Risk and limits
-	RiskLevel      string   `json:"risk_level"`
-	MinDepositUSD  *float64 `json:"min_deposit_usd,omitempty"`
-	MaxDepositUSD  *float64 `json:"max_deposit_usd,omitempty"`
-	
-	// Status
-	IsActive     bool        `json:"is_active"`
-	StableCoin   bool        `json:"stable_coin"`
-	
-	// Additional data
-	Metadata     interface{} `json:"metadata,omitempty"`
-	CreatedAt    time.Time   `json:"created_at"`
-	UpdatedAt    time.Time   `json:"updated_at"`
+// ProtocolTVLSnapshot is a point-in-time DefiLlama TVL reading for a protocol
+type ProtocolTVLSnapshot struct {
+	ProtocolID uuid.UUID          `json:"-"`
+	TVLUSD     float64            `json:"tvl_usd"`
+	ChainTVLs  map[string]float64 `json:"chain_tvls"` // Keyed by DefiLlama chain name
+	RecordedAt time.Time          `json:"recorded_at"`
 }
 
-// TokenBalance represents a token balance in a position
-type TokenBalance struct {
-	TokenID    uuid.UUID `json:"token_id"`
-	Token      *Token    `json:"token,omitempty"`
-	Balance    string    `json:"balance"`
-	BalanceUSD *float64  `json:"balance_usd,omitempty"`
+// PortfolioValuation is a user's total wallet value from the latest valuation snapshot,
+// with the totals it is compared against
+type PortfolioValuation struct {
+	UserID     uuid.UUID `json:"user_id"`
+	ValueUSD   float64   `json:"value_usd"`
+	RecordedAt time.Time `json:"recorded_at"`
+	// PreviousValueUSD is the total at the snapshot before the latest one
+	PreviousValueUSD *float64 `json:"previous_value_usd,omitempty"`
+	// DayAgoValueUSD is the total at the latest snapshot at least 24 hours older
+	DayAgoValueUSD *float64 `json:"day_ago_value_usd,omitempty"`
 }
 
-// RewardInfo represents reward information
-type RewardInfo struct {
-	TokenID   uuid.UUID `json:"token_id"`
-	Token     *Token    `json:"token,omitempty"`
-	Amount    string    `json:"amount"`
-	AmountUSD *float64  `json:"amount_usd,omitempty"`
-	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+// ProtocolTVL is a protocol's current TVL with its chain breakdown and history
+type ProtocolTVL struct {
+	Protocol         *Protocol              `json:"protocol"`
+	TVLUSD           float64                `json:"tvl_usd"`
+	ChainTVLs        map[string]float64     `json:"chain_tvls"`
+	Change24hPercent *float64               `json:"change_24h_percent"`
+	History          []*ProtocolTVLSnapshot `json:"history"`
 }
 
-// YieldPosition represents a user's position in a yield pool
-type YieldPosition struct {
-	ID         uuid.UUID `json:"id"`
-	UserID     uuid.UUID `json:"user_id"`
-	WalletID   uuid.UUID `json:"wallet_id"`
-	PoolID     uuid.UUID `json:"pool_id"`
-	ProtocolID *uuid.UUID `json:"protocol_id,omitempty"`
-	
-	// Relations
-	User     *User      `json:"user,omitempty"`
-	Wallet   *Wallet    `json:"wallet,omitempty"`
-	Pool     *YieldPool `json:"pool,omitempty"`
-	Protocol *Protocol  `json:"protocol,omitempty"`
-	
-	// Position details
-	PositionID            *string `json:"position_id,omitempty"` // External position ID
-	PoolAddress           *string `json:"pool_address,omitempty"`
-	ChainID               int     `json:"chain_id"`
-	
-	// Balance information
-	BalanceRaw            string          `json:"balance_raw"`
-	BalanceUSD            *float64        `json:"balance_usd,omitempty"`
-	BalanceTokens         []TokenBalance  `json:"balance_tokens,omitempty"`
-	
-	// Entry information
+// Lending protocols with debt position support
+const (
+	DebtProtocolAaveV3     = "aave_v3"
+	DebtProtocolCompoundV3 = "compound_v3"
+)
+
+// DebtPosition is a wallet's borrow position on a lending market. USD values come from
+// the protocol's own oracle so they match what its liquidation logic sees.
+type DebtPosition struct {
+	Protocol             string  `json:"protocol"`
+	ChainID              int     `json:"chain_id"`
+	Market               string  `json:"market"` // Aave pool or Compound Comet address
+	WalletAddress        string  `json:"wallet_address"`
+	CollateralUSD        float64 `json:"collateral_usd"`
+	DebtUSD              float64 `json:"debt_usd"`
+	AvailableBorrowsUSD  float64 `json:"available_borrows_usd"`
+	LiquidationThreshold float64 `json:"liquidation_threshold"` // Weighted, as a fraction of collateral
+	LTV                  float64 `json:"ltv"`
+	HealthFactor         float64 `json:"health_factor"` // Liquidatable below 1
+	// LiquidationDropPercent is how far collateral value can fall, with debt unchanged, before liquidation
+	LiquidationDropPercent float64 `json:"liquidation_drop_percent"`
+	DebtAsset              *string `json:"debt_asset,omitempty"`
+	// CollateralAsset and LiquidationPrice are only set for single-collateral positions
+	CollateralAsset  *string  `json:"collateral_asset,omitempty"`
+	LiquidationPrice *float64 `json:"liquidation_price,omitempty"`
+}
+
+// Vault is an ERC-4626 vault as read from its contract
+type Vault struct {
+	ChainID       int     `json:"chain_id"`
+	Address       string  `json:"address"`
+	Asset         string  `json:"asset"`
+	AssetSymbol   string  `json:"asset_symbol"`
+	AssetDecimals int     `json:"asset_decimals"`
+	ShareDecimals int     `json:"share_decimals"`
+	TotalAssets   float64 `json:"total_assets"`
+	TotalSupply   float64 `json:"total_supply"`
+	SharePrice    float64 `json:"share_price"` // Assets per share
+}
+
+// VaultPosition is a wallet's shares in an ERC-4626 vault, valued at what the vault
+// would redeem them for
+type VaultPosition struct {
+	ChainID       int    `json:"chain_id"`
+	Vault         string `json:"vault"`
+	WalletAddress string `json:"wallet_address"`
+	Asset         string `json:"asset"`
+	AssetSymbol   string `json:"asset_symbol"`
+	// Raw amounts are in base units
+	Shares     float64  `json:"shares"`
+	SharesRaw  string   `json:"shares_raw"`
+	Assets     float64  `json:"assets"`
+	AssetsRaw  string   `json:"assets_raw"`
+	SharePrice float64  `json:"share_price"`
+	ValueUSD   *float64 `json:"value_usd,omitempty"`
+	// The yield pool the vault is listed as, if any
+	PoolID *uuid.UUID `json:"pool_id,omitempty"`
+	APY    *float64   `json:"apy,omitempty"`
+}
+
+// Liquidation risk levels, in escalating order
+const (
+	LiquidationRiskOK       = "ok"
+	LiquidationRiskWatch    = "watch"
+	LiquidationRiskWarning  = "warning"
+	LiquidationRiskCritical = "critical"
+)
+
+// LiquidationRiskState is the monitored escalation level of a health factor alert
+type LiquidationRiskState struct {
+	AlertID       uuid.UUID  `json:"alert_id"`
+	Level         string     `json:"level"`
+	HealthFactor  *float64   `json:"health_factor,omitempty"`
+	NotifiedLevel string     `json:"notified_level"`
+	NotifiedAt    *time.Time `json:"notified_at,omitempty"`
+	CheckedAt     time.Time  `json:"checked_at"`
+}
+
+// Perpetuals venues tracked for derivative positions
+const (
+	DerivativeVenueGMXV2       = "gmx_v2"
+	DerivativeVenueHyperliquid = "hyperliquid"
+)
+
+// Derivative position sides
+const (
+	DerivativeSideLong  = "long"
+	DerivativeSideShort = "short"
+)
+
+// DerivativePosition is an open (or last seen) perpetual position on a derivatives venue
+type DerivativePosition struct {
+	ID               uuid.UUID `json:"id"`
+	WalletAddress    string    `json:"wallet_address"`
+	Venue            string    `json:"venue"`
+	ChainID          *int      `json:"chain_id,omitempty"`
+	Market           string    `json:"market"` // GMX market token address or Hyperliquid coin
+	Symbol           string    `json:"symbol"`
+	Side             string    `json:"side"`
+	Size             float64   `json:"size"` // In index tokens
+	SizeUSD          float64   `json:"size_usd"` // Notional at the mark price
+	EntryPrice       float64   `json:"entry_price"`
+	MarkPrice        *float64  `json:"mark_price,omitempty"`
+	LiquidationPrice *float64  `json:"liquidation_price,omitempty"`
+	Leverage         *float64  `json:"leverage,omitempty"`
+	CollateralUSD    float64   `json:"collateral_usd"`
+	UnrealizedPnLUSD float64   `json:"unrealized_pnl_usd"`
+	// FundingSinceOpenUSD is positive when funding was received; nil when the venue doesn't report it
+	FundingSinceOpenUSD *float64   `json:"funding_since_open_usd,omitempty"`
+	IsOpen              bool       `json:"is_open"`
+	OpenedAt            time.Time  `json:"opened_at"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// EquityUSD is what the position is worth to the wallet: collateral plus unrealized PnL
+func (p *DerivativePosition) EquityUSD() float64 {
+	return p.CollateralUSD + p.UnrealizedPnLUSD
+}
+
+// FundingPayment is one settled funding payment on a perpetual position
+type FundingPayment struct {
+	WalletAddress string    `json:"wallet_address"`
+	Venue         string    `json:"venue"`
+	Market        string    `json:"market"`
+	AmountUSD     float64   `json:"amount_usd"` // Positive when received, negative when paid
+	FundingRate   *float64  `json:"funding_rate,omitempty"`
+	PositionSize  *float64  `json:"position_size,omitempty"`
+	PaidAt        time.Time `json:"paid_at"`
+}
+
+// DerivativesPnLSummary reports perpetual positions alongside token PnL
+type DerivativesPnLSummary struct {
+	UnrealizedPnLUSD float64 `json:"unrealized_pnl_usd"`
+	// FundingPaidUSD and FundingReceivedUSD are settled in the requested period
+	FundingPaidUSD     float64               `json:"funding_paid_usd"`
+	FundingReceivedUSD float64               `json:"funding_received_usd"`
+	NetFundingUSD      float64               `json:"net_funding_usd"`
+	TotalPnLUSD        float64               `json:"total_pnl_usd"`
+	OpenPositions      int                   `json:"open_positions"`
+	Positions          []*DerivativePosition `json:"positions"`
+}
+
+// ETH staking protocols
+const (
+	StakingProtocolLido       = "lido"
+	StakingProtocolRocketPool = "rocket_pool"
+	StakingProtocolCoinbase   = "coinbase"
+	StakingProtocolSolo       = "solo"
+)
+
+// LiquidStakingPosition is a liquid staking token holding valued in the ETH it redeems for.
+// Units are the protocol's non-rebasing accounting unit (stETH shares, or the token itself).
+type LiquidStakingPosition struct {
+	Protocol      string  `json:"protocol"`
+	ChainID       int     `json:"chain_id"`
+	WalletAddress string  `json:"wallet_address"`
+	Token         string  `json:"token"`
+	Symbol        string  `json:"symbol"`
+	Balance       float64 `json:"balance"` // In tokens, as a wallet shows it
+	Units         float64 `json:"units"`
+	ExchangeRate  float64 `json:"exchange_rate"` // ETH per unit
+	UnderlyingETH float64 `json:"underlying_eth"`
+	// PrincipalETH is the ETH value of the units when they were first seen; the
+	// difference to UnderlyingETH is the staking yield accrued since
+	PrincipalETH float64   `json:"principal_eth"`
+	AccruedETH   float64   `json:"accrued_eth"`
+	ValueUSD     *float64  `json:"value_usd,omitempty"`
+	AccruedUSD   *float64  `json:"accrued_usd,omitempty"`
+	TrackedSince time.Time `json:"tracked_since"`
+}
+
+// LiquidStakingState is the stored principal of a wallet's liquid staking token
+type LiquidStakingState struct {
+	WalletAddress string    `json:"wallet_address"`
+	ChainID       int       `json:"chain_id"`
+	Token         string    `json:"token"`
+	Units         float64   `json:"units"`
+	PrincipalETH  float64   `json:"principal_eth"`
+	TrackedSince  time.Time `json:"tracked_since"`
+}
+
+// StakingValidator is a beacon chain validator a user tracks as a solo staker
+type StakingValidator struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ValidatorIndex int64     `json:"validator_index"`
+	Label          *string   `json:"label,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// AddStakingValidatorRequest tracks a validator by its beacon chain index
+type AddStakingValidatorRequest struct {
+	ValidatorIndex int64   `json:"validator_index" validate:"min=0"`
+	Label          *string `json:"label,omitempty"`
+}
+
+// Reward lock kinds
+const (
+	// RewardLockKindVoteEscrow locks tokens for voting power until a date (veCRV-style)
+	RewardLockKindVoteEscrow = "vote_escrow"
+	// RewardLockKindVesting releases tokens linearly between two dates after a cliff
+	RewardLockKindVesting = "vesting"
+)
+
+// Reward lock sources
+const (
+	RewardLockSourceOnchain = "onchain"
+	RewardLockSourceManual  = "manual"
+)
+
+// RewardLock is an amount of tokens a wallet can't withdraw yet
+type RewardLock struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	WalletAddress string     `json:"wallet_address"`
+	ChainID       int        `json:"chain_id"`
+	Protocol      string     `json:"protocol"`
+	Kind          string     `json:"kind"`
+	Source        string     `json:"source"`
+	Contract      string     `json:"contract"`
+	TokenAddress  string     `json:"token_address"`
+	TokenSymbol   string     `json:"token_symbol"`
+	Amount        float64    `json:"amount"`
+	Released      float64    `json:"released"`
+	VotingPower   *float64   `json:"voting_power,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	CliffAt       *time.Time `json:"cliff_at,omitempty"`
+	UnlocksAt     time.Time  `json:"unlocks_at"`
+	SyncedAt      *time.Time `json:"synced_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Unlocked is how much of Amount can be withdrawn now, set when reading locks
+	Unlocked float64 `json:"unlocked"`
+	// NextUnlockAt is the next cliff or final unlock still ahead
+	NextUnlockAt *time.Time `json:"next_unlock_at,omitempty"`
+}
+
+// UnlockedAt returns how much of the lock can be withdrawn at t
+func (l *RewardLock) UnlockedAt(t time.Time) float64 {
+	if !t.Before(l.UnlocksAt) {
+		return l.Amount
+	}
+	if l.Kind != RewardLockKindVesting || l.StartsAt == nil || !t.After(*l.StartsAt) {
+		return 0
+	}
+	if l.CliffAt != nil && t.Before(*l.CliffAt) {
+		return 0
+	}
+	return l.Amount * t.Sub(*l.StartsAt).Seconds() / l.UnlocksAt.Sub(*l.StartsAt).Seconds()
+}
+
+// NextUnlock returns the cliff or final unlock after t, or nil once fully unlocked
+func (l *RewardLock) NextUnlock(t time.Time) *time.Time {
+	if l.Kind == RewardLockKindVesting && l.CliffAt != nil && l.CliffAt.After(t) {
+		return l.CliffAt
+	}
+	if l.UnlocksAt.After(t) {
+		return &l.UnlocksAt
+	}
+	return nil
+}
+
+// CreateVestingScheduleRequest tracks tokens vesting to one of the user's wallets
+type CreateVestingScheduleRequest struct {
+	WalletAddress string     `json:"wallet_address" validate:"required"`
+	ChainID       int        `json:"chain_id" validate:"required"`
+	Protocol      string     `json:"protocol" validate:"required,max=50"`
+	Contract      string     `json:"contract" validate:"required"`
+	TokenAddress  string     `json:"token_address" validate:"required"`
+	TokenSymbol   string     `json:"token_symbol" validate:"required,max=20"`
+	Amount        float64    `json:"amount" validate:"gt=0"`
+	Released      float64    `json:"released" validate:"min=0"`
+	StartsAt      time.Time  `json:"starts_at" validate:"required"`
+	CliffAt       *time.Time `json:"cliff_at,omitempty"`
+	UnlocksAt     time.Time  `json:"unlocks_at" validate:"required"`
+}
+
+// VestingCalendarMonth lists what unlocks in one calendar month
+type VestingCalendarMonth struct {
+	Month   string         `json:"month"` // YYYY-MM
+	Unlocks []RewardUnlock `json:"unlocks"`
+	// TotalsBySymbol sums Unlocks per token symbol
+	TotalsBySymbol map[string]float64 `json:"totals_by_symbol"`
+}
+
+// RewardUnlock is the part of a lock unlocking in a calendar month
+type RewardUnlock struct {
+	LockID      uuid.UUID `json:"lock_id"`
+	Protocol    string    `json:"protocol"`
+	Kind        string    `json:"kind"`
+	TokenSymbol string    `json:"token_symbol"`
+	Amount      float64   `json:"amount"`
+	// At is set when the amount unlocks at once, at a cliff or the final unlock; linear
+	// vesting over the month leaves it nil
+	At *time.Time `json:"at,omitempty"`
+}
+
+// ValidatorPosition is a tracked validator's live beacon chain state
+type ValidatorPosition struct {
+	ValidatorIndex      int64   `json:"validator_index"`
+	Label               *string `json:"label,omitempty"`
+	Pubkey              string  `json:"pubkey,omitempty"`
+	Status              string  `json:"status"`
+	BalanceETH          float64 `json:"balance_eth"`
+	EffectiveBalanceETH float64 `json:"effective_balance_eth"`
+	// AccruedETH is lifetime consensus layer rewards, including withdrawn skims. Execution
+	// rewards go to the fee recipient, which shows up as a wallet balance instead.
+	AccruedETH float64  `json:"accrued_eth"`
+	ValueUSD   *float64 `json:"value_usd,omitempty"`
+	AccruedUSD *float64 `json:"accrued_usd,omitempty"`
+}
+
+// StakingPositions is the staking section of the positions API
+type StakingPositions struct {
+	LiquidStaking   []*LiquidStakingPosition `json:"liquid_staking"`
+	Validators      []*ValidatorPosition     `json:"validators"`
+	TotalETH        float64                  `json:"total_eth"`
+	TotalAccruedETH float64                  `json:"total_accrued_eth"`
+	TotalValueUSD   *float64                 `json:"total_value_usd,omitempty"`
+	ETHPriceUSD     *float64                 `json:"eth_price_usd,omitempty"`
+}
+
+// Bitcoin account kinds: a single address or an extended public key (xpub, ypub, zpub)
+const (
+	BitcoinAccountAddress = "address"
+	BitcoinAccountXpub    = "xpub"
+)
+
+// BitcoinAccount is a Bitcoin address or xpub a user tracks read-only
+type BitcoinAccount struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	Kind         string     `json:"kind"`
+	Identifier   string     `json:"identifier"` // The address or extended public key
+	Label        *string    `json:"label,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// AddBitcoinAccountRequest tracks a Bitcoin address or extended public key
+type AddBitcoinAccountRequest struct {
+	Identifier string  `json:"identifier" validate:"required"`
+	Label      *string `json:"label,omitempty"`
+}
+
+// BitcoinTransaction is a confirmed transaction's net effect on a tracked account.
+// AmountSats is negative when the account spent, and then includes the fee it paid.
+type BitcoinTransaction struct {
+	ID          uuid.UUID `json:"id"`
+	AccountID   uuid.UUID `json:"account_id"`
+	Txid        string    `json:"txid"`
+	BlockHeight int64     `json:"block_height"`
+	Timestamp   time.Time `json:"timestamp"`
+	AmountSats  int64     `json:"amount_sats"`
+	FeeSats     int64     `json:"fee_sats"`
+	PriceUSD    *float64  `json:"price_usd,omitempty"` // BTC price on the day of the transaction
+}
+
+// BitcoinAccountBalance is a tracked account's live balance
+type BitcoinAccountBalance struct {
+	Account      *BitcoinAccount `json:"account"`
+	AddressCount int             `json:"address_count"` // Addresses with history; 1 for single addresses
+	BalanceSats  int64           `json:"balance_sats"`
+	BalanceBTC   float64         `json:"balance_btc"`
+	ValueUSD     *float64        `json:"value_usd,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// BitcoinPortfolio is the user's Bitcoin holdings across tracked accounts
+type BitcoinPortfolio struct {
+	Accounts      []*BitcoinAccountBalance `json:"accounts"`
+	TotalSats     int64                    `json:"total_sats"`
+	TotalBTC      float64                  `json:"total_btc"`
+	TotalValueUSD *float64                 `json:"total_value_usd,omitempty"`
+	BTCPriceUSD   *float64                 `json:"btc_price_usd,omitempty"`
+}
+
+// ExchangeAccount is a centralized exchange account read with a user's read-only API key.
+// The key is stored encrypted and never returned.
+type ExchangeAccount struct {
+	ID                   uuid.UUID  `json:"id"`
+	UserID               uuid.UUID  `json:"user_id"`
+	Exchange             string     `json:"exchange"` // binance, coinbase or kraken
+	Label                *string    `json:"label,omitempty"`
+	KeyHint              string     `json:"key_hint"`
+	EncryptedCredentials []byte     `json:"-"`
+	LastSyncedAt         *time.Time `json:"last_synced_at,omitempty"`
+	LastError            *string    `json:"last_error,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// AddExchangeAccountRequest connects an exchange account. For Coinbase the API key is the
+// CDP key name and the secret its private key.
+type AddExchangeAccountRequest struct {
+	Exchange  string  `json:"exchange" validate:"required"`
+	APIKey    string  `json:"api_key" validate:"required"`
+	APISecret string  `json:"api_secret" validate:"required"`
+	Label     *string `json:"label,omitempty"`
+}
+
+// ExchangeAssetBalance is an exchange account's spot holding of one asset as last synced
+type ExchangeAssetBalance struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Asset     string    `json:"asset"`
+	AssetRef  *AssetRef `json:"asset_id,omitempty"`
+	Free      float64   `json:"free"`
+	Locked    float64   `json:"locked"`
+	PriceUSD  *float64  `json:"price_usd,omitempty"`
+	ValueUSD  *float64  `json:"value_usd,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExchangeTrade is an executed spot trade. Quantity is in the base asset and Price in the
+// quote asset; QuotePriceUSD is the quote asset's USD price on the day of the trade.
+type ExchangeTrade struct {
+	ID            uuid.UUID `json:"id"`
+	AccountID     uuid.UUID `json:"account_id"`
+	Exchange      string    `json:"exchange"`
+	TradeID       string    `json:"trade_id"`
+	BaseAsset     string    `json:"base_asset"`
+	QuoteAsset    string    `json:"quote_asset"`
+	Side          string    `json:"side"` // buy or sell of the base asset
+	Quantity      float64   `json:"quantity"`
+	Price         float64   `json:"price"`
+	Fee           float64   `json:"fee"`
+	FeeAsset      string    `json:"fee_asset"`
+	QuotePriceUSD *float64  `json:"quote_price_usd,omitempty"`
+	ExecutedAt    time.Time `json:"executed_at"`
+}
+
+// ExchangeAccountHoldings is an exchange account with its synced balances
+type ExchangeAccountHoldings struct {
+	Account  *ExchangeAccount        `json:"account"`
+	Balances []*ExchangeAssetBalance `json:"balances"`
+	ValueUSD float64                 `json:"value_usd"`
+}
+
+// ExchangePortfolio is the user's holdings across connected exchange accounts
+type ExchangePortfolio struct {
+	Accounts      []*ExchangeAccountHoldings `json:"accounts"`
+	TotalValueUSD float64                    `json:"total_value_usd"`
+}
+
+// Paper trade sides. Yield credits an asset as if earned from a position, without
+// spending cash.
+const (
+	PaperTradeBuy   = "buy"
+	PaperTradeSell  = "sell"
+	PaperTradeYield = "yield"
+)
+
+// PaperPortfolio is a simulated portfolio: cash the user trades at live prices without
+// real wallets, to test a strategy or try the app out
+type PaperPortfolio struct {
+	ID              uuid.UUID `json:"id"`
+	UserID          uuid.UUID `json:"user_id"`
+	Name            string    `json:"name"`
+	StartingCashUSD float64   `json:"starting_cash_usd"`
+	CashUSD         float64   `json:"cash_usd"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PaperTrade is a simulated buy, sell or yield entry, executed at the price of the moment
+type PaperTrade struct {
+	ID          uuid.UUID `json:"id"`
+	PortfolioID uuid.UUID `json:"portfolio_id"`
+	Side        string    `json:"side"`
+	Asset       string    `json:"asset"`
+	Quantity    float64   `json:"quantity"`
+	PriceUSD    float64   `json:"price_usd"`
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+// CreatePaperPortfolioRequest starts a paper portfolio with StartingCashUSD, or the
+// default amount when unset
+type CreatePaperPortfolioRequest struct {
+	Name            string   `json:"name" validate:"required,max=100"`
+	StartingCashUSD *float64 `json:"starting_cash_usd,omitempty"`
+}
+
+// PaperTradeRequest simulates a trade of Quantity of the asset, or of AmountUSD worth of
+// it at the live price; exactly one of the two is set
+type PaperTradeRequest struct {
+	Side      string  `json:"side" validate:"required,oneof=buy sell yield"`
+	Asset     string  `json:"asset" validate:"required"`
+	Quantity  float64 `json:"quantity,omitempty"`
+	AmountUSD float64 `json:"amount_usd,omitempty"`
+}
+
+// PaperPosition is an asset held in a paper portfolio with its PnL. Assets that couldn't
+// be priced have no value or unrealized PnL.
+type PaperPosition struct {
+	Asset            string   `json:"asset"`
+	Quantity         string   `json:"quantity"`
+	PriceUSD         *float64 `json:"price_usd,omitempty"`
+	ValueUSD         *float64 `json:"value_usd,omitempty"`
+	CostBasisUSD     string   `json:"cost_basis_usd"`
+	RealizedPnLUSD   string   `json:"realized_pnl_usd"`
+	UnrealizedPnLUSD string   `json:"unrealized_pnl_usd,omitempty"`
+	YieldUSD         float64  `json:"yield_usd"`
+}
+
+// PaperPortfolioSummary is a paper portfolio valued at live prices
+type PaperPortfolioSummary struct {
+	Portfolio     *PaperPortfolio  `json:"portfolio"`
+	Positions     []*PaperPosition `json:"positions"`
+	TotalValueUSD float64          `json:"total_value_usd"` // cash plus priced positions
+	ReturnPct     float64          `json:"return_pct"`      // against the starting cash
+}
+
+// Leaderboards ranking the participants' portfolios
+const (
+	// LeaderboardReturns ranks by the change in portfolio value this month
+	LeaderboardReturns = "returns"
+	// LeaderboardDiversified ranks by how evenly value is spread across tokens
+	LeaderboardDiversified = "diversified"
+)
+
+// LeaderboardParticipant is a user who opted in to the leaderboards
+type LeaderboardParticipant struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Alias     string    `json:"alias"`
+	Hidden    bool      `json:"hidden"` // taken off the boards by an admin
+	OptedInAt time.Time `json:"opted_in_at"`
+}
+
+// LeaderboardParticipationRequest opts the user in to or out of the leaderboards
+type LeaderboardParticipationRequest struct {
+	OptIn bool `json:"opt_in"`
+}
+
+// LeaderboardParticipantVisibilityRequest lets admins take a participant off the boards
+type LeaderboardParticipantVisibilityRequest struct {
+	Hidden bool `json:"hidden"`
+}
+
+// LeaderboardEntry is one participant's place on a leaderboard. What Score measures
+// depends on the board: the return in percent, or the effective number of tokens held.
+type LeaderboardEntry struct {
+	Rank   int     `json:"rank"`
+	Alias  string  `json:"alias"`
+	Score  float64 `json:"score"`
+	Tokens int     `json:"tokens,omitempty"` // tokens held, on the diversified board
+}
+
+// Leaderboard ranks the opted-in users on one board
+type Leaderboard struct {
+	Board       string              `json:"board"`
+	Since       *time.Time          `json:"since,omitempty"` // start of the period, for boards covering one
+	Entries     []*LeaderboardEntry `json:"entries"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// PlatformTokenStat is how widely a token is held across the platform
+type PlatformTokenStat struct {
+	Symbol   string  `json:"symbol"`
+	Holders  int     `json:"holders"`
+	ValueUSD float64 `json:"value_usd"`
+}
+
+// PlatformStats aggregates the wallets tracked on the platform. Private wallets are left
+// out, and tokens are only listed once enough users hold them that no one stands out.
+type PlatformStats struct {
+	TotalValueUSD float64              `json:"total_value_usd"`
+	Wallets       int                  `json:"wallets"`
+	Users         int                  `json:"users"`
+	TopTokens     []*PlatformTokenStat `json:"top_tokens"`
+	GeneratedAt   time.Time            `json:"generated_at"`
+}
+
+// TrendingCoin is one of the coins most searched on CoinGecko
+type TrendingCoin struct {
+	Rank           int      `json:"rank"`
+	CoinGeckoID    string   `json:"coingecko_id"`
+	Symbol         string   `json:"symbol"`
+	Name           string   `json:"name"`
+	MarketCapRank  *int     `json:"market_cap_rank,omitempty"`
+	ThumbURL       *string  `json:"thumb_url,omitempty"`
+	PriceUSD       *float64 `json:"price_usd,omitempty"`
+	PriceChange24h *float64 `json:"price_change_24h,omitempty"` // in percent
+}
+
+// MarketMover is a token held on the platform with its price move over the last day
+type MarketMover struct {
+	Symbol         string  `json:"symbol"`
+	Name           string  `json:"name"`
+	LogoURI        *string `json:"logo_uri,omitempty"`
+	PriceUSD       float64 `json:"price_usd"`
+	PriceChange24h float64 `json:"price_change_24h"` // in percent
+}
+
+// APYChange is a yield pool whose APY moved over the last day
+type APYChange struct {
+	PoolID      string   `json:"pool_id"`
+	Protocol    string   `json:"protocol"`
+	Symbol      string   `json:"symbol"`
+	Chain       string   `json:"chain"`
+	TVLUSD      float64  `json:"tvl_usd"`
+	APY         float64  `json:"apy"`
+	APYChange1d float64  `json:"apy_change_1d"`           // in percentage points
+	APYChange7d *float64 `json:"apy_change_7d,omitempty"` // in percentage points
+}
+
+// MarketOverview is the wallet-independent market summary shown before sign-in
+type MarketOverview struct {
+	Trending    []*TrendingCoin `json:"trending"`
+	Gainers     []*MarketMover  `json:"gainers"`
+	Losers      []*MarketMover  `json:"losers"`
+	APYChanges  []*APYChange    `json:"apy_changes"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// FeedSource is an RSS feed or Discourse forum ingested into the news feed
+type FeedSource struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	// Kind is feeds.KindRSS or feeds.KindDiscourse
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+	// ProtocolID and Tokens are what the source's posts are about
+	ProtocolID    *uuid.UUID `json:"protocol_id,omitempty"`
+	Tokens        []string   `json:"tokens"`
+	Enabled       bool       `json:"enabled"`
+	LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// CreateFeedSourceRequest adds a feed source. Protocol is a protocol slug.
+type CreateFeedSourceRequest struct {
+	Name     string   `json:"name" validate:"required,max=100"`
+	Kind     string   `json:"kind" validate:"required,oneof=rss discourse"`
+	URL      string   `json:"url" validate:"required,url"`
+	Protocol *string  `json:"protocol,omitempty"`
+	Tokens   []string `json:"tokens,omitempty"`
+}
+
+// FeedItem is a post in the news feed
+type FeedItem struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Summary     *string   `json:"summary,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	SourceName  string    `json:"source_name"`
+	// Protocol is the slug of the protocol the post is about
+	Protocol *string  `json:"protocol,omitempty"`
+	Tokens   []string `json:"tokens"`
+}
+
+// CosmosDelegation is stake delegated to a validator on a Cosmos SDK chain with its
+// unclaimed rewards. Amounts are in the token's smallest unit, like Balance.
+type CosmosDelegation struct {
+	Chain            ChainRef `json:"chain"`
+	ValidatorAddress string   `json:"validator_address"`
+	Token            *Token   `json:"token"`
+	Amount           string   `json:"amount"`
+	AmountUSD        *float64 `json:"amount_usd,omitempty"`
+	Rewards          string   `json:"rewards"`
+	RewardsUSD       *float64 `json:"rewards_usd,omitempty"`
+}
+
+// YieldPool represents a yield farming pool with enhanced information
+type YieldPool struct {
+	ID             uuid.UUID      `json:"id"`
+	PoolID         string         `json:"pool_id"`
+	ProtocolID     *uuid.UUID     `json:"protocol_id,omitempty"`
+	Protocol       *Protocol      `json:"protocol,omitempty"`
+	PoolName       string         `json:"pool_name"`
+	ChainID        *int           `json:"chain_id,omitempty"`
+	Chain          string         `json:"chain"`
+	PoolAddress    *string        `json:"pool_address,omitempty"`
+	Symbol         string         `json:"symbol"`
+	TokenAddresses []string       `json:"token_addresses,omitempty"`
+	
+	// Financial metrics
+	TVLUSD       *float64 `json:"tvl_usd,omitempty"`
+	APY          *float64 `json:"apy,omitempty"`
+	APYBase      *float64 `json:"apy_base,omitempty"`
+	APYReward    *float64 `json:"apy_reward,omitempty"`
+	FeesAPR      *float64 `json:"fees_apr,omitempty"`
+	IL7D         *float64 `json:"il_7d,omitempty"` // Impermanent loss 7 days
+	
+	// Risk and limits
+	RiskLevel      string   `json:"risk_level"`
+	MinDepositUSD  *float64 `json:"min_deposit_usd,omitempty"`
+	MaxDepositUSD  *float64 `json:"max_deposit_usd,omitempty"`
+	
+	// Status
+	IsActive     bool        `json:"is_active"`
+	StableCoin   bool        `json:"stable_coin"`
+	// SuccessorPoolID is the pool the protocol migrated this one to, deprecating it
+	SuccessorPoolID *string    `json:"successor_pool_id,omitempty"`
+	DeprecatedAt    *time.Time `json:"deprecated_at,omitempty"`
+	// Successor is the live pool at the end of the pool's migrations, when asked for
+	Successor *YieldPool `json:"successor,omitempty"`
+	
+	// Additional data
+	Metadata     interface{} `json:"metadata,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// PoolMetadataRewards is the yield pool metadata key holding the pool's []RewardAPR
+const PoolMetadataRewards = "rewards"
+
+// Reward APR sources
+const (
+	RewardSourceDefiLlama = "defillama"
+	RewardSourceOnchain   = "onchain"
+)
+
+// RewardAPR is one reward token's part of a pool's reward APY
+type RewardAPR struct {
+	Token  string `json:"token"`
+	Symbol string `json:"symbol,omitempty"`
+	// APR is nil when the source only reports the total reward APY of several tokens
+	APR *float64 `json:"apr,omitempty"`
+	// EmissionPerDay is how many tokens the pool distributes a day, where known
+	EmissionPerDay *float64   `json:"emission_per_day,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	Source         string     `json:"source"`
+}
+
+// RewardAPRs returns the reward breakdown stored in the pool's metadata
+func (p *YieldPool) RewardAPRs() []RewardAPR {
+	metadata, ok := p.Metadata.(map[string]interface{})
+	if !ok || metadata[PoolMetadataRewards] == nil {
+		return nil
+	}
+	// Metadata is decoded generically, so round-trip the entry into its type
+	raw, err := json.Marshal(metadata[PoolMetadataRewards])
+	if err != nil {
+		return nil
+	}
+	var rewards []RewardAPR
+	if err := json.Unmarshal(raw, &rewards); err != nil {
+		return nil
+	}
+	return rewards
+}
+
+// PoolRewards breaks a pool's reward APY down by reward token
+type PoolRewards struct {
+	PoolID    uuid.UUID `json:"pool_id"`
+	APY       *float64  `json:"apy,omitempty"`
+	APYBase   *float64  `json:"apy_base,omitempty"`
+	APYReward *float64  `json:"apy_reward,omitempty"`
+	// EmissionShare is the percentage of the APY paid out as reward emissions
+	EmissionShare *float64 `json:"emission_share,omitempty"`
+	// EmissionsEndAt is when the earliest known emission schedule ends
+	EmissionsEndAt *time.Time  `json:"emissions_end_at,omitempty"`
+	Rewards        []RewardAPR `json:"rewards"`
+}
+
+// RewardShare is one token's part of a position's pending rewards
+type RewardShare struct {
+	TokenID   uuid.UUID `json:"token_id"`
+	Symbol    string    `json:"symbol,omitempty"`
+	AmountUSD *float64  `json:"amount_usd,omitempty"`
+	// Share is the percentage of the priced pending rewards
+	Share *float64 `json:"share,omitempty"`
+}
+
+// TokenBalance represents a token balance in a position
+type TokenBalance struct {
+	TokenID    uuid.UUID `json:"token_id"`
+	Token      *Token    `json:"token,omitempty"`
+	Balance    string    `json:"balance"`
+	BalanceUSD *float64  `json:"balance_usd,omitempty"`
+}
+
+// RewardInfo represents reward information
+type RewardInfo struct {
+	TokenID   uuid.UUID `json:"token_id"`
+	Token     *Token    `json:"token,omitempty"`
+	Amount    string    `json:"amount"`
+	AmountUSD *float64  `json:"amount_usd,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+}
+
+// YieldPosition represents a user's position in a yield pool
+type YieldPosition struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	WalletID   uuid.UUID `json:"wallet_id"`
+	PoolID     uuid.UUID `json:"pool_id"`
+	ProtocolID *uuid.UUID `json:"protocol_id,omitempty"`
+	
+	// Relations
+	User     *User      `json:"user,omitempty"`
+	Wallet   *Wallet    `json:"wallet,omitempty"`
+	Pool     *YieldPool `json:"pool,omitempty"`
+	Protocol *Protocol  `json:"protocol,omitempty"`
+	
+	// Position details
+	PositionID            *string `json:"position_id,omitempty"` // External position ID
+	PoolAddress           *string `json:"pool_address,omitempty"`
+	ChainID               int     `json:"chain_id"`
+	
+	// Balance information
+	BalanceRaw            string          `json:"balance_raw"`
+	BalanceUSD            *float64        `json:"balance_usd,omitempty"`
+	BalanceTokens         []TokenBalance  `json:"balance_tokens,omitempty"`
+	
+	// Entry information
 	EntryPriceUSD         *float64  `json:"entry_price_usd,omitempty"`
 	EntryBlockNumber      *int64    `json:"entry_block_number,omitempty"`
 	EntryTransactionHash  *string   `json:"entry_transaction_hash,omitempty"`
 	EntryTime             time.Time `json:"entry_time"`
+	// EntryTokenPrices is each pool token's USD price at entry, keyed by lowercase address
+	EntryTokenPrices      map[string]float64 `json:"entry_token_prices,omitempty"`
+	// MigratedFromPositionID is the wallet's position in the deprecated pool this one
+	// carries on, whose entry, rewards and realized PnL it took over
+	MigratedFromPositionID *uuid.UUID `json:"migrated_from_position_id,omitempty"`
 	
 	// Current status
 	IsActive              bool       `json:"is_active"`
@@ -214,6 +1779,8 @@ type YieldPosition struct {
 	
 	// Rewards information
 	PendingRewards        []RewardInfo `json:"pending_rewards,omitempty"`
+	// RewardComposition splits PendingRewards by token
+	RewardComposition     []RewardShare `json:"reward_composition,omitempty"`
 	ClaimedRewards        []RewardInfo `json:"claimed_rewards,omitempty"`
 	TotalRewardsUSD       *float64     `json:"total_rewards_usd,omitempty"`
 	
@@ -232,6 +1799,26 @@ type YieldPosition struct {
 	UpdatedAt             time.Time   `json:"updated_at"`
 }
 
+// SetYieldPoolSuccessorRequest deprecates a pool in favour of the one it migrated to
+type SetYieldPoolSuccessorRequest struct {
+	SuccessorPoolID string `json:"successor_pool_id" validate:"required"`
+}
+
+// YieldPoolMigrationNotice is an active position in a deprecated pool whose owner is
+// yet to hear of the migration
+type YieldPoolMigrationNotice struct {
+	PositionID      uuid.UUID
+	UserID          uuid.UUID
+	Email           *string
+	PoolID          string
+	PoolName        string
+	Chain           string
+	SuccessorID     uuid.UUID
+	SuccessorPoolID string
+	SuccessorName   string
+	SuccessorAPY    *float64
+}
+
 // PositionSummary represents aggregated position information for a user
 type PositionSummary struct {
 	TotalValueUSD       float64         `json:"total_value_usd"`
@@ -255,8 +1842,19 @@ type PnLLot struct {
 	RemainingQuantity string    `json:"remaining_quantity"`
 	BlockNumber       int64     `json:"block_number"`
 	Timestamp         time.Time `json:"timestamp"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	Source            string    `json:"source"` // 'onchain' or 'manual'
+	// InternalTransferID pairs a transfer between the user's own wallets, which
+	// realizes no PnL
+	InternalTransferID *uuid.UUID `json:"internal_transfer_id,omitempty"`
+	// CostBasisUSD is the per-unit cost basis carried in by a transfer; when set it
+	// replaces PriceUSD as the lot's cost
+	CostBasisUSD       *string    `json:"cost_basis_usd,omitempty"`
+	// WashSale flags a purchase that replaced a token sold at a loss; any loss deferred
+	// into its cost basis is in WashSaleAdjustmentUSD
+	WashSale              bool    `json:"wash_sale,omitempty"`
+	WashSaleAdjustmentUSD *string `json:"wash_sale_adjustment_usd,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 // PnLCalculation represents the result of a PnL calculation
@@ -272,9 +1870,74 @@ type PnLCalculation struct {
 	CurrentValueUSD   string               `json:"current_value_usd"`
 	CurrentQuantity   string               `json:"current_quantity"`
 	Lots              []PnLLot             `json:"lots"`
+	Asset             *AssetRef            `json:"asset,omitempty"`
+	NFT               *NFTPnLSummary       `json:"nft,omitempty"`
+	Derivatives       *DerivativesPnLSummary `json:"derivatives,omitempty"`
+	Income            *IncomeSummary       `json:"income,omitempty"` // reported separately from realized PnL
+	Jurisdiction      string               `json:"jurisdiction,omitempty"`
+	WashSales         []WashSale           `json:"wash_sales,omitempty"`
+	DeferredLossUSD   string               `json:"deferred_loss_usd,omitempty"` // wash-sale losses left out of realized PnL
 	CalculatedAt      time.Time            `json:"calculated_at"`
 }
 
+// WashSale is a loss on a token sold and re-purchased within a jurisdiction's window
+type WashSale struct {
+	SellTransactionHash        string    `json:"sell_transaction_hash"`
+	SoldAt                     time.Time `json:"sold_at"`
+	ReplacementTransactionHash string    `json:"replacement_transaction_hash"`
+	ReplacedAt                 time.Time `json:"replaced_at"`
+	Quantity                   string    `json:"quantity"`
+	LossUSD                    string    `json:"loss_usd"`
+	DeferredLossUSD            string    `json:"deferred_loss_usd"`
+}
+
+// NFTTransfer represents an ERC-721/ERC-1155 transfer into or out of a tracked wallet
+type NFTTransfer struct {
+	ID              uuid.UUID `json:"id"`
+	WalletID        uuid.UUID `json:"wallet_id"`
+	ChainID         int       `json:"chain_id"`
+	ContractAddress string    `json:"contract_address"`
+	TokenID         string    `json:"token_id"`
+	Standard        string    `json:"standard"` // 'erc721' or 'erc1155'
+	FromAddress     string    `json:"from_address"`
+	ToAddress       string    `json:"to_address"`
+	Quantity        int64     `json:"quantity"`
+	Direction       string    `json:"direction"` // 'in' or 'out'
+	ValueUSD        *float64  `json:"value_usd,omitempty"`
+	TransactionHash string    `json:"transaction_hash"`
+	BlockNumber     int64     `json:"block_number"`
+	BlockHash       *string   `json:"block_hash,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Asset maps the transferred NFT into the chain-agnostic asset model
+func (t *NFTTransfer) Asset() Asset {
+	return NFTAsset(t.ChainID, t.ContractAddress, t.TokenID, t.Standard)
+}
+
+// NFTLot represents a single acquired NFT unit and, once sold or sent, its disposal
+type NFTLot struct {
+	ContractAddress string     `json:"contract_address"`
+	TokenID         string     `json:"token_id"`
+	AcquiredTxHash  string     `json:"acquired_tx_hash"`
+	AcquiredAt      time.Time  `json:"acquired_at"`
+	CostUSD         float64    `json:"cost_usd"`
+	DisposedTxHash  *string    `json:"disposed_tx_hash,omitempty"`
+	DisposedAt      *time.Time `json:"disposed_at,omitempty"`
+	ProceedsUSD     *float64   `json:"proceeds_usd,omitempty"`
+	RealizedPnLUSD  *float64   `json:"realized_pnl_usd,omitempty"`
+}
+
+// NFTPnLSummary represents NFT cost basis and realized PnL for a wallet
+type NFTPnLSummary struct {
+	RealizedPnLUSD   float64  `json:"realized_pnl_usd"`
+	CostBasisHeldUSD float64  `json:"cost_basis_held_usd"`
+	HeldCount        int      `json:"held_count"`
+	DisposedCount    int      `json:"disposed_count"`
+	Lots             []NFTLot `json:"lots"`
+}
+
 // PnLExportData represents data structure for CSV export
 type PnLExportData struct {
 	WalletAddress     string    `csv:"wallet_address"`
@@ -288,28 +1951,122 @@ type PnLExportData struct {
 	RealizedPnLUSD    string    `csv:"realized_pnl_usd"`
 	Timestamp         time.Time `csv:"timestamp"`
 	BlockNumber       int64     `csv:"block_number"`
+	WashSale              bool   `csv:"wash_sale"`
+	WashSaleAdjustmentUSD string `csv:"wash_sale_adjustment_usd"`
 }
 
-// Alert represents an alert configuration
-type Alert struct {
-	ID                uuid.UUID       `json:"id"`
-	UserID            uuid.UUID       `json:"user_id"`
-	Type              string          `json:"type"`
-	Status            string          `json:"status"`
+// Income categories of inbound transfers, taxed as income at fair market value on receipt
+const (
+	IncomeStakingReward = "staking_reward"
+	IncomeAirdrop       = "airdrop"
+	IncomeInterest      = "interest"
+	IncomeCashback      = "cashback"
+	IncomeMining        = "mining"
+	IncomeOther         = "other"
+	// IncomeNotIncome is an override excluding a transfer the heuristics count as income
+	IncomeNotIncome = "not_income"
+)
+
+// How an income event was classified
+const (
+	IncomeSourceOverride = "override" // the user's classification
+	IncomeSourceManual   = "manual"   // the type given in a manual import
+	IncomeSourceProtocol = "protocol" // received from a known reward or distributor contract
+	IncomeSourceMethod   = "method"   // the decoded method name of the transaction
+)
+
+// IncomeEvent is an inbound transfer classified as income, valued at its price on receipt
+type IncomeEvent struct {
+	TransactionHash string    `json:"transaction_hash"`
+	TokenSymbol     string    `json:"token_symbol"`
+	TokenAddress    string    `json:"token_address"`
+	Quantity        float64         `json:"quantity"`
+	PriceUSD        decimal.Decimal `json:"price_usd"`
+	ValueUSD        decimal.Decimal `json:"value_usd"`
+	Category        string          `json:"category"`
+	ClassifiedBy    string          `json:"classified_by"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// IncomeSummary totals a wallet's income over a period by category
+type IncomeSummary struct {
+	TotalUSD   decimal.Decimal            `json:"total_usd"`
+	ByCategory map[string]decimal.Decimal `json:"by_category"`
+	Events     []IncomeEvent      `json:"events"`
+}
+
+// Alert represents an alert configuration
+type Alert struct {
+	ID                uuid.UUID       `json:"id"`
+	UserID            uuid.UUID       `json:"user_id"`
+	Type              string          `json:"type"`
+	Status            string          `json:"status"`
 	Target            AlertTarget     `json:"target"`
 	Conditions        AlertConditions `json:"conditions"`
 	Notification      AlertNotification `json:"notification"`
 	LastTriggeredAt   *time.Time      `json:"last_triggered_at,omitempty"`
+	MutedUntil        *time.Time      `json:"muted_until,omitempty"`
 	TriggerCount      int             `json:"trigger_count"`
+	// EscalationPolicyID routes triggers through a team escalation policy instead of
+	// only notifying the owner
+	EscalationPolicyID *uuid.UUID `json:"escalation_policy_id,omitempty"`
 	CreatedAt         time.Time       `json:"created_at"`
 	UpdatedAt         time.Time       `json:"updated_at"`
+	// Coverage is only filled in on the alert detail endpoint
+	Coverage *AlertCoverage `json:"coverage,omitempty"`
+	// ExpiredReason says why an expired alert's target disappeared, one of the
+	// AlertExpired* values; re-pointing the alert to a successor clears it
+	ExpiredReason *string    `json:"expired_reason,omitempty"`
+	ExpiredAt     *time.Time `json:"expired_at,omitempty"`
 }
 
 // AlertTarget represents the target entity for an alert
 type AlertTarget struct {
-	Type       string `json:"type"`        // token, address, pool
-	Identifier string `json:"identifier"`  // token address, wallet address, pool ID
+	Type       string `json:"type"`        // token, address, pool, portfolio, position, saved_search, reward_lock
+	Identifier string `json:"identifier"`  // token address, wallet address, pool ID, position ID, saved search ID, reward lock ID
 	ChainID    int    `json:"chainId"`
+	// Asset identifies token targets on any chain; it is kept in sync with Identifier and ChainID
+	Asset *AssetRef `json:"asset,omitempty"`
+}
+
+// ResolveAsset fills in a token target from whichever of Asset or Identifier and ChainID
+// is given. Price alerts read the tokens table, so only EVM tokens and bitcoin resolve.
+func (t *AlertTarget) ResolveAsset() error {
+	if t.Asset == nil {
+		if t.Type == "token" && t.Identifier != "" {
+			token := Token{Address: t.Identifier, ChainID: t.ChainID}
+			ref := token.Asset().ID
+			t.Asset = &ref
+		}
+		return nil
+	}
+
+	if t.Type == "" {
+		t.Type = "token"
+	}
+	if t.Type != "token" {
+		return fmt.Errorf("asset can only be set on token targets")
+	}
+
+	ref := *t.Asset
+	switch {
+	case ref.Chain.Namespace == ChainNamespaceEVM && ref.Namespace == AssetNamespaceERC20:
+		t.Identifier = strings.ToLower(ref.Reference)
+	case ref.Chain.Namespace == ChainNamespaceEVM && ref.Namespace == AssetNamespaceSLIP44:
+		t.Identifier = evmZeroAddress
+	case ref.Chain == BitcoinChain && ref.Namespace == AssetNamespaceSLIP44:
+		t.Identifier, t.ChainID = BTCTokenAddress, 0
+		return nil
+	default:
+		return fmt.Errorf("alerts are not supported for asset %s", ref)
+	}
+
+	chainID, ok := ref.Chain.EVMChainID()
+	if !ok {
+		return fmt.Errorf("invalid EVM chain in asset %s", ref)
+	}
+	t.ChainID = chainID
+	return nil
 }
 
 // AlertConditions represents the conditions that trigger an alert
@@ -317,8 +2074,10 @@ type AlertConditions struct {
 	// Price alerts
 	Price         *float64 `json:"price,omitempty"`
 	
-	// Transfer alerts  
+	// Transfer alerts fire on a transfer above Threshold, a raw amount in wei, or above
+	// ThresholdUSD, valued at the native token's price when the alert is evaluated
 	Threshold     *string  `json:"threshold,omitempty"` // Wei amount
+	ThresholdUSD  *float64 `json:"thresholdUsd,omitempty"`
 	
 	// Liquidity alerts
 	ChangePercent *float64 `json:"changePercent,omitempty"`
@@ -326,12 +2085,100 @@ type AlertConditions struct {
 	// APR alerts
 	MinAPR        *float64 `json:"minAPR,omitempty"`
 	MaxAPR        *float64 `json:"maxAPR,omitempty"`
+
+	// Composite alerts
+	Rule          *AlertConditionNode `json:"rule,omitempty"`
+
+	// Health factor alerts fire when a debt position's health factor drops below this
+	HealthFactor  *float64 `json:"healthFactor,omitempty"`
+
+	// Portfolio value alerts fire when total USD value crosses above or below these,
+	// or moves by ChangePercent in either direction over 24 hours
+	ValueAbove    *float64 `json:"valueAbove,omitempty"`
+	ValueBelow    *float64 `json:"valueBelow,omitempty"`
+
+	// Position APY alerts fire when the held pool's APY falls APYDropPercent below
+	// BaselineAPY, or below MinAPR. BaselineAPY defaults to the APY when the alert is
+	// first evaluated.
+	APYDropPercent *float64 `json:"apyDropPercent,omitempty"`
+	BaselineAPY    *float64 `json:"baselineAPY,omitempty"`
+
+	// Impermanent loss alerts fire when an LP position's loss against holding its tokens
+	// exceeds this percentage
+	ILPercent      *float64 `json:"ilPercent,omitempty"`
+
+	// Unlock reminders fire this many days before a reward lock's cliff or final unlock
+	DaysBefore     *int     `json:"daysBefore,omitempty"`
+
+	// Balance change alerts fire when a wallet's balance of any token moves by at least
+	// ChangeUSD, only in Direction if it's set
+	ChangeUSD      *float64 `json:"changeUsd,omitempty"`
+	Direction      string   `json:"direction,omitempty"`
+
+	// Budget exceeded alerts fire once a month, when the owner's spending on
+	// BudgetMetric reaches BudgetPercent of their budget for it, 100 by default
+	BudgetMetric   string   `json:"budgetMetric,omitempty"`
+	BudgetPercent  *float64 `json:"budgetPercent,omitempty"`
+}
+
+// AlertConditionNode is one node of a composite alert's condition tree. Group
+// nodes set Operator and Children; leaf nodes set Metric, Comparator and Value.
+//
+//	{"operator":"and","children":[
+//	  {"metric":"token_price","comparator":"lt","value":2000},
+//	  {"metric":"gas_price_gwei","comparator":"lt","value":20}]}
+//
+// The any_pool operator matches when at least one yield pool passing PoolFilter
+// satisfies all children, with pool_* metrics bound to that pool.
+type AlertConditionNode struct {
+	Operator   string               `json:"operator,omitempty"` // and, or, any_pool
+	Children   []AlertConditionNode `json:"children,omitempty"`
+	PoolFilter *AlertPoolFilter     `json:"poolFilter,omitempty"`
+
+	Metric     string       `json:"metric,omitempty"`     // token_price, gas_price_gwei, pool_apy, pool_tvl_usd
+	Comparator string       `json:"comparator,omitempty"` // gt, gte, lt, lte
+	Value      *float64     `json:"value,omitempty"`
+	Target     *AlertTarget `json:"target,omitempty"` // Defaults to the alert's target
 }
 
+// AlertPoolFilter narrows the pools an any_pool node ranges over
+type AlertPoolFilter struct {
+	StableCoin *bool   `json:"stableCoin,omitempty"`
+	Chain      string  `json:"chain,omitempty"`
+	Protocol   string  `json:"protocol,omitempty"`
+	MinTVLUSD  float64 `json:"minTvlUsd,omitempty"`
+}
+
+// Composite condition operators, metrics and comparators
+const (
+	ConditionOperatorAnd     = "and"
+	ConditionOperatorOr      = "or"
+	ConditionOperatorAnyPool = "any_pool"
+
+	ConditionMetricTokenPrice   = "token_price"
+	ConditionMetricGasPriceGwei = "gas_price_gwei"
+	ConditionMetricPoolAPY      = "pool_apy"
+	ConditionMetricPoolTVLUSD   = "pool_tvl_usd"
+
+	ConditionComparatorGT  = "gt"
+	ConditionComparatorGTE = "gte"
+	ConditionComparatorLT  = "lt"
+	ConditionComparatorLTE = "lte"
+)
+
 // AlertNotification represents notification preferences
 type AlertNotification struct {
 	Email   bool   `json:"email"`
 	Webhook string `json:"webhook,omitempty"`
+	// Telegram is the chat ID the bot posts to
+	Telegram string `json:"telegram,omitempty"`
+	// Discord is a Discord channel webhook URL
+	Discord string `json:"discord,omitempty"`
+	InApp   bool   `json:"in_app,omitempty"`
+	// Template replaces the default message text on every channel. {{name}}
+	// placeholders are filled in with the variables services.NotificationTemplateVariables
+	// lists.
+	Template string `json:"template,omitempty"`
 }
 
 // AlertHistory represents a triggered alert event  
@@ -353,6 +2200,27 @@ const (
 	AlertTypeApproval        = "approval"
 	AlertTypeLiquidityChange = "liquidity_change"
 	AlertTypeAPRChange       = "apr_change"
+	AlertTypeComposite       = "composite"
+	AlertTypeHealthFactor    = "health_factor"
+	AlertTypePortfolioValue  = "portfolio_value"
+	AlertTypePositionAPYDrop = "position_apy_drop"
+	AlertTypeILThreshold     = "il_threshold"
+	AlertTypeNewMatch        = "new_match"
+	AlertTypeUnlockReminder  = "unlock_reminder"
+	AlertTypeBalanceChange   = "balance_change"
+	AlertTypeBudgetExceeded  = "budget_exceeded"
+)
+
+// Alert target types for alerts that aren't about a token, address or pool
+const (
+	// AlertTargetTypePortfolio targets the alert owner's whole portfolio
+	AlertTargetTypePortfolio = "portfolio"
+	// AlertTargetTypePosition targets one of the owner's yield positions by its ID
+	AlertTargetTypePosition = "position"
+	// AlertTargetTypeSavedSearch targets one of the owner's saved yield searches by its ID
+	AlertTargetTypeSavedSearch = "saved_search"
+	// AlertTargetTypeRewardLock targets one of the owner's reward locks by its ID
+	AlertTargetTypeRewardLock = "reward_lock"
 )
 
 // Alert status constants
@@ -363,9 +2231,45 @@ const (
 	AlertStatusDisabled  = "disabled"
 )
 
+// Reasons an alert's pool or token target disappeared
+const (
+	AlertExpiredPoolNotFound    = "pool_not_found"
+	AlertExpiredPoolDeactivated = "pool_deactivated"
+	// AlertExpiredPoolDelisted is a pool DefiLlama stopped reporting
+	AlertExpiredPoolDelisted  = "pool_delisted"
+	AlertExpiredTokenNotFound = "token_not_found"
+	// AlertExpiredTokenDelisted is a token CoinGecko doesn't list whose price stopped updating
+	AlertExpiredTokenDelisted = "token_delisted"
+)
+
+// AlertTargetEventGone is the event of the notification sent when an alert's target
+// disappears, in place of the values of a trigger
+const AlertTargetEventGone = "target_gone"
+
+// GoneAlertTarget is an alert whose pool or token target disappeared, and why
+type GoneAlertTarget struct {
+	AlertID uuid.UUID
+	Reason  string
+}
+
+// AlertTargetSuccessor is a pool or token an expired alert can be re-pointed to
+type AlertTargetSuccessor struct {
+	Target AlertTarget `json:"target"`
+	Name   string      `json:"name"`
+	Symbol string      `json:"symbol"`
+	// TVLUSD is set for pools and MarketCap for tokens, which successors are ranked by
+	TVLUSD    *float64 `json:"tvl_usd,omitempty"`
+	MarketCap *float64 `json:"market_cap,omitempty"`
+}
+
+// RepointAlertRequest moves an expired alert to a successor of its target
+type RepointAlertRequest struct {
+	Target AlertTarget `json:"target" validate:"required"`
+}
+
 // CreateAlertRequest represents the request to create an alert
 type CreateAlertRequest struct {
-	Type         string            `json:"type" validate:"required,oneof=price_above price_below large_transfer approval liquidity_change apr_change"`
+	Type         string            `json:"type" validate:"required"` // A built-in type or one registered with services.RegisterAlertEvaluator
 	Target       AlertTarget       `json:"target" validate:"required"`
 	Conditions   AlertConditions   `json:"conditions" validate:"required"`
 	Notification AlertNotification `json:"notification" validate:"required"`
@@ -378,6 +2282,405 @@ type UpdateAlertRequest struct {
 	Notification *AlertNotification `json:"notification,omitempty"`
 }
 
+// MuteAlertRequest mutes an alert until a time or for a duration; both empty unmutes
+type MuteAlertRequest struct {
+	MutedUntil      *time.Time `json:"muted_until,omitempty"`
+	DurationMinutes *int       `json:"duration_minutes,omitempty"`
+}
+
+// AlertPack is a portable set of alert definitions users share. Notification settings
+// are never part of one, and targets personal to the exporter are replaced by {{name}}
+// placeholders the importer fills in.
+type AlertPack struct {
+	Version     int    `json:"version"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Placeholders lists the names the alerts' targets leave to the importer
+	Placeholders []string         `json:"placeholders,omitempty"`
+	Alerts       []AlertPackAlert `json:"alerts"`
+}
+
+// AlertPackAlert is one alert definition of a pack
+type AlertPackAlert struct {
+	Type       string          `json:"type"`
+	Target     AlertTarget     `json:"target"`
+	Conditions AlertConditions `json:"conditions"`
+	// Template is the alert's message template, if it has one
+	Template string `json:"template,omitempty"`
+}
+
+// ExportAlertPackRequest exports some of the user's alerts as a pack
+type ExportAlertPackRequest struct {
+	AlertIDs    []uuid.UUID `json:"alert_ids"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+}
+
+// ImportAlertPackRequest creates a pack's alerts for the user, filling in its
+// placeholders from Values and notifying them through Notification
+type ImportAlertPackRequest struct {
+	Pack         AlertPack         `json:"pack"`
+	Values       map[string]string `json:"values,omitempty"`
+	Notification AlertNotification `json:"notification"`
+}
+
+// AlertPackImportResult lists the alerts an import created and the pack entries that
+// were rejected, by their index in the pack
+type AlertPackImportResult struct {
+	Created []*Alert                 `json:"created"`
+	Failed  []AlertPackImportFailure `json:"failed"`
+}
+
+type AlertPackImportFailure struct {
+	Index int    `json:"index"`
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// AlertConfig is the full set of alerts a user wants, applied with PUT /alerts/config.
+// Alerts are matched to existing ones by key.
+type AlertConfig struct {
+	Alerts []AlertConfigEntry `json:"alerts"`
+}
+
+// AlertConfigEntry is one desired alert. Status is active or disabled, active if empty.
+type AlertConfigEntry struct {
+	Key          string            `json:"key"`
+	Type         string            `json:"type"`
+	Status       string            `json:"status,omitempty"`
+	Target       AlertTarget       `json:"target"`
+	Conditions   AlertConditions   `json:"conditions"`
+	Notification AlertNotification `json:"notification"`
+}
+
+// Alert config plan actions
+const (
+	AlertConfigCreate = "create"
+	AlertConfigUpdate = "update"
+	// AlertConfigReplace recreates an alert whose type or target changed, which alerts
+	// can't be updated in place for
+	AlertConfigReplace = "replace"
+	AlertConfigDelete  = "delete"
+)
+
+// AlertConfigPlan lists the changes that converge a user's alerts on a config. When
+// Applied, Error is set on the changes that failed.
+type AlertConfigPlan struct {
+	Applied   bool                `json:"applied"`
+	Changes   []AlertConfigChange `json:"changes"`
+	Unchanged int                 `json:"unchanged"`
+}
+
+type AlertConfigChange struct {
+	Action  string     `json:"action"`
+	Key     string     `json:"key"`
+	AlertID *uuid.UUID `json:"alert_id,omitempty"`
+	// Fields lists what an update or replace changes
+	Fields []string `json:"fields,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// AlertCheck is one look the evaluator took at an alert. Stale checks ran on data
+// older than the evaluator accepts; failed checks couldn't evaluate the alert at all.
+type AlertCheck struct {
+	AlertID   uuid.UUID
+	CheckedAt time.Time
+	Stale     bool
+	Failed    bool
+	// DataAt is when the data the check ran on was from, when known
+	DataAt *time.Time
+}
+
+// AlertCoverageBucket sums an alert's checks over one hour
+type AlertCoverageBucket struct {
+	Bucket        time.Time
+	Checks        int
+	StaleChecks   int
+	FailedChecks  int
+	LastCheckedAt time.Time
+	LastDataAt    *time.Time
+}
+
+// Coverage gap reasons
+const (
+	// CoverageGapNotEvaluated is an hour the alert wasn't evaluated at all
+	CoverageGapNotEvaluated = "not_evaluated"
+	// CoverageGapStaleData is an hour every evaluation ran on stale data
+	CoverageGapStaleData = "stale_data"
+	// CoverageGapFailed is an hour every evaluation failed
+	CoverageGapFailed = "failed"
+	// CoverageGapCooldown is an hour the alert was cooling down after a trigger, which
+	// isn't a gap in monitoring so much as the alert resting
+	CoverageGapCooldown = "cooldown"
+)
+
+// AlertCoverage tells whether an alert was actually being watched over a window, so
+// "no alert" can be told apart from "nobody was looking"
+type AlertCoverage struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// ExpectedChecks is how many evaluations the schedule should have run in the window
+	ExpectedChecks int `json:"expected_checks"`
+	Checks         int `json:"checks"`
+	StaleChecks    int `json:"stale_checks"`
+	FailedChecks   int `json:"failed_checks"`
+	// CoveragePercent is the share of expected evaluations that ran on fresh data
+	CoveragePercent float64            `json:"coverage_percent"`
+	LastCheckedAt   *time.Time         `json:"last_checked_at,omitempty"`
+	LastDataAt      *time.Time         `json:"last_data_at,omitempty"`
+	Gaps            []AlertCoverageGap `json:"gaps"`
+}
+
+// AlertCoverageGap is a run of hours the alert wasn't properly watched
+type AlertCoverageGap struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// AlertBacktestRequest replays an alert definition against stored history over
+// [From, To), the last 30 days by default
+type AlertBacktestRequest struct {
+	Type       string          `json:"type"`
+	Target     AlertTarget     `json:"target"`
+	Conditions AlertConditions `json:"conditions"`
+	From       *time.Time      `json:"from,omitempty"`
+	To         *time.Time      `json:"to,omitempty"`
+}
+
+// AlertBacktestResult lists when a backtested alert would have triggered. Triggers are
+// the snapshots where its condition started to hold; MatchedPoints counts every
+// snapshot it held at.
+type AlertBacktestResult struct {
+	Type          string                 `json:"type"`
+	From          time.Time              `json:"from"`
+	To            time.Time              `json:"to"`
+	DataPoints    int                    `json:"data_points"`
+	MatchedPoints int                    `json:"matched_points"`
+	Triggers      []AlertBacktestTrigger `json:"triggers"`
+}
+
+type AlertBacktestTrigger struct {
+	TriggeredAt    time.Time              `json:"triggered_at"`
+	TriggeredValue map[string]interface{} `json:"triggered_value"`
+}
+
+// HistoryPoint is one snapshot of a price, APY or TVL series
+type HistoryPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// NotificationSettings holds a user's quiet hours, interpreted in their timezone
+type NotificationSettings struct {
+	UserID            uuid.UUID `json:"user_id"`
+	QuietHoursEnabled bool      `json:"quiet_hours_enabled"`
+	QuietHoursStart   string    `json:"quiet_hours_start"` // HH:MM
+	QuietHoursEnd     string    `json:"quiet_hours_end"`   // HH:MM
+	Timezone          string    `json:"timezone"`
+	QuietHoursAction  string    `json:"quiet_hours_action"` // queue, drop
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// UpdateNotificationSettingsRequest represents a partial update of notification settings
+type UpdateNotificationSettingsRequest struct {
+	QuietHoursEnabled *bool   `json:"quiet_hours_enabled,omitempty"`
+	QuietHoursStart   *string `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd     *string `json:"quiet_hours_end,omitempty"`
+	Timezone          *string `json:"timezone,omitempty"`
+	QuietHoursAction  *string `json:"quiet_hours_action,omitempty" validate:"omitempty,oneof=queue drop"`
+}
+
+// Quiet hours actions
+const (
+	QuietHoursActionQueue = "queue"
+	QuietHoursActionDrop  = "drop"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail    = "email"
+	NotificationChannelWebhook  = "webhook"
+	NotificationChannelTelegram = "telegram"
+	NotificationChannelDiscord  = "discord"
+	NotificationChannelInApp    = "in_app"
+)
+
+// Email delivery statuses. A sent email moves on to delivered, bounced or complained as
+// the provider reports back; suppressed ones were never sent.
+const (
+	EmailStatusSent       = "sent"
+	EmailStatusFailed     = "failed"
+	EmailStatusSuppressed = "suppressed"
+	EmailStatusDelivered  = "delivered"
+	EmailStatusBounced    = "bounced"
+	EmailStatusComplained = "complained"
+)
+
+// EmailDelivery is one email sent, or held back, through the email provider
+type EmailDelivery struct {
+	ID                uuid.UUID `json:"id"`
+	Template          string    `json:"template"`
+	To                string    `json:"to"`
+	Subject           string    `json:"subject"`
+	Provider          string    `json:"provider"`
+	ProviderMessageID *string   `json:"provider_message_id,omitempty"`
+	Status            string    `json:"status"`
+	Attempts          int       `json:"attempts"`
+	Error             *string   `json:"error,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Email suppression reasons
+const (
+	EmailSuppressionHardBounce = "hard_bounce"
+	EmailSuppressionComplaint  = "complaint"
+	EmailSuppressionManual     = "manual"
+)
+
+// EmailSuppression is an address nothing is emailed to
+type EmailSuppression struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"`
+	Detail    *string   `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateEmailSuppressionRequest suppresses an address by hand
+type CreateEmailSuppressionRequest struct {
+	Email  string  `json:"email" validate:"required,email"`
+	Detail *string `json:"detail,omitempty"`
+}
+
+// AlertNotificationMessage is the payload delivered to notification channels
+type AlertNotificationMessage struct {
+	AlertID        uuid.UUID              `json:"alert_id"`
+	HistoryID      uuid.UUID              `json:"history_id"`
+	Type           string                 `json:"type"`
+	Target         AlertTarget            `json:"target"`
+	TriggeredAt    time.Time              `json:"triggered_at"`
+	TriggeredValue map[string]interface{} `json:"triggered_value"`
+	// DeliveryID is the same for every attempt to deliver this trigger on one channel,
+	// so receivers can drop duplicates
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	// Text is the message rendered for people to read, set per channel when sending
+	Text string `json:"text,omitempty"`
+}
+
+// NotificationTemplate is a ready-made message template users can start from
+type NotificationTemplate struct {
+	Name string `json:"name"`
+	// AlertTypes the template's variables make sense for; empty means any
+	AlertTypes  []string `json:"alert_types,omitempty"`
+	Description string   `json:"description"`
+	Template    string   `json:"template"`
+}
+
+// NotificationTemplateVariable is a placeholder message templates can use
+type NotificationTemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// PreviewNotificationTemplateRequest renders a template for an alert definition.
+// TriggeredValue defaults to example values for the alert type.
+type PreviewNotificationTemplateRequest struct {
+	Template       string                 `json:"template"`
+	Type           string                 `json:"type"`
+	Target         AlertTarget            `json:"target"`
+	TriggeredValue map[string]interface{} `json:"triggered_value,omitempty"`
+}
+
+// NotificationTemplatePreview is a rendered template with the variables it was given
+type NotificationTemplatePreview struct {
+	Text      string            `json:"text"`
+	Variables map[string]string `json:"variables"`
+}
+
+// OutboxNotification is a triggered alert waiting in the outbox to be dispatched
+type OutboxNotification struct {
+	ID           uuid.UUID         `json:"id"`
+	AlertID      uuid.UUID         `json:"alert_id"`
+	History      AlertHistory      `json:"history"`
+	Notification AlertNotification `json:"notification"`
+	Urgent       bool              `json:"urgent"`
+	Attempts     int               `json:"attempts"`
+	LastError    *string           `json:"last_error,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// QueuedNotification is a notification held back until the user's quiet hours end
+type QueuedNotification struct {
+	ID           uuid.UUID                `json:"id"`
+	AlertID      uuid.UUID                `json:"alert_id"`
+	HistoryID    uuid.UUID                `json:"history_id"`
+	UserID       uuid.UUID                `json:"user_id"`
+	Channel      string                   `json:"channel"`
+	Destination  string                   `json:"destination"`
+	Payload      AlertNotificationMessage `json:"payload"`
+	DeliverAfter time.Time                `json:"deliver_after"`
+	Attempts     int                      `json:"attempts"`
+	LastError    *string                  `json:"last_error,omitempty"`
+	CreatedAt    time.Time                `json:"created_at"`
+}
+
+// Alert delivery statuses
+const (
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+	DeliveryStatusDropped = "dropped"
+)
+
+// AlertDelivery records the outcome of delivering a triggered alert to one channel
+type AlertDelivery struct {
+	ID          uuid.UUID `json:"id"`
+	DeliveryID  uuid.UUID `json:"delivery_id"`
+	AlertID     uuid.UUID `json:"alert_id"`
+	HistoryID   uuid.UUID `json:"history_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Channel     string    `json:"channel"`
+	Status      string    `json:"status"`
+	Error       *string   `json:"error,omitempty"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	DeliveredAt time.Time `json:"delivered_at"`
+	LatencyMs   int64     `json:"latency_ms"`
+}
+
+// AlertStats summarises how a user's alerts fired and were delivered over a period
+type AlertStats struct {
+	Period          string                 `json:"period"`
+	Since           time.Time              `json:"since"`
+	Triggers        []AlertTriggerBucket   `json:"triggers"`
+	Channels        []ChannelDeliveryStats `json:"channels"`
+	MedianLatencyMs *float64               `json:"median_latency_ms"`
+	NoisiestAlerts  []NoisyAlert           `json:"noisiest_alerts"`
+}
+
+// AlertTriggerBucket is the number of alert triggers in one time bucket
+type AlertTriggerBucket struct {
+	Bucket time.Time `json:"bucket"`
+	Count  int       `json:"count"`
+}
+
+// ChannelDeliveryStats holds delivery outcomes for one notification channel
+type ChannelDeliveryStats struct {
+	Channel     string  `json:"channel"`
+	Sent        int     `json:"sent"`
+	Failed      int     `json:"failed"`
+	Dropped     int     `json:"dropped"`
+	SuccessRate float64 `json:"success_rate"` // sent / (sent + failed)
+}
+
+// NoisyAlert is an alert ranked by how often it fired
+type NoisyAlert struct {
+	AlertID      uuid.UUID   `json:"alert_id"`
+	Type         string      `json:"type"`
+	Target       AlertTarget `json:"target"`
+	TriggerCount int         `json:"trigger_count"`
+}
+
 // Watchlist represents a user's watchlist item
 type Watchlist struct {
 	ID         uuid.UUID `json:"id"`
@@ -411,13 +2714,15 @@ type FeatureFlag struct {
 
 // SystemBanner represents a system-wide banner notification
 type SystemBanner struct {
-	ID        uuid.UUID `json:"id"`
-	Title     *string   `json:"title,omitempty"`
-	Message   string    `json:"message"`
-	Level     string    `json:"level"`
-	Active    bool      `json:"active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID      uuid.UUID `json:"id"`
+	Title   *string   `json:"title,omitempty"`
+	Message string    `json:"message"`
+	Level   string    `json:"level"`
+	Active  bool      `json:"active"`
+	// BroadcastID is set on banners sent with a broadcast, which only its recipients see
+	BroadcastID *uuid.UUID `json:"broadcast_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // Banner level constants
@@ -428,6 +2733,69 @@ const (
 	BannerLevelSuccess = "success"
 )
 
+// BroadcastAudience picks the users a broadcast reaches: holders of a token, users with
+// active positions in a protocol, or, for an incident in the news feed, the users holding
+// what the item's source is about. Users in any of the segments given are included.
+type BroadcastAudience struct {
+	ChainID      *int    `json:"chain_id,omitempty"`
+	TokenAddress *string `json:"token_address,omitempty"`
+	// Protocol is a protocol slug
+	Protocol   *string    `json:"protocol,omitempty"`
+	FeedItemID *uuid.UUID `json:"feed_item_id,omitempty"`
+}
+
+// CreateBroadcastRequest sends a one-off message to an audience, as a banner only they
+// see, an email, or both
+type CreateBroadcastRequest struct {
+	Audience BroadcastAudience `json:"audience"`
+	Title    *string           `json:"title,omitempty"`
+	Message  string            `json:"message"`
+	Level    string            `json:"level"`
+	Banner   bool              `json:"banner"`
+	Email    bool              `json:"email"`
+}
+
+// BroadcastPreview is who a broadcast reaches
+type BroadcastPreview struct {
+	Users int `json:"users"`
+	// Emailable is how many of the users have an email address
+	Emailable int `json:"emailable"`
+	// Sample is some of the users' addresses, to check the audience is the one meant
+	Sample []string `json:"sample"`
+}
+
+// Broadcast is a one-off message admins sent to an audience
+type Broadcast struct {
+	ID         uuid.UUID         `json:"id"`
+	Title      *string           `json:"title,omitempty"`
+	Message    string            `json:"message"`
+	Level      string            `json:"level"`
+	Audience   BroadcastAudience `json:"audience"`
+	Banner     bool              `json:"banner"`
+	Email      bool              `json:"email"`
+	Recipients int               `json:"recipients"`
+	// EmailsPending is how many of its emails the broadcast email job has yet to send
+	EmailsPending int        `json:"emails_pending"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BroadcastResult is what sending a broadcast returns: who it reaches and, unless it was
+// a dry run, the broadcast sent
+type BroadcastResult struct {
+	Preview   *BroadcastPreview `json:"preview"`
+	Broadcast *Broadcast        `json:"broadcast,omitempty"`
+}
+
+// BroadcastEmail is a recipient's broadcast email waiting to be sent
+type BroadcastEmail struct {
+	BroadcastID uuid.UUID
+	UserID      uuid.UUID
+	Email       string
+	Title       *string
+	Message     string
+}
+
 // CreateFeatureFlagRequest represents the request to create/update a feature flag
 type CreateFeatureFlagRequest struct {
 	Name  string                 `json:"name" validate:"required"`
@@ -448,4 +2816,1504 @@ type UpdateSystemBannerRequest struct {
 	Message *string `json:"message,omitempty"`
 	Level   *string `json:"level,omitempty" validate:"omitempty,oneof=info warning error success"`
 	Active  *bool   `json:"active,omitempty"`
-}
\ No newline at end of file
+}
+
+// ImpersonationSession lets an admin view one user's data read-only for support
+type ImpersonationSession struct {
+	ID        uuid.UUID  `json:"id"`
+	AdminID   uuid.UUID  `json:"admin_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Reason    string     `json:"reason"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// StartImpersonationRequest opens an impersonation session; the reason is audited
+type StartImpersonationRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+	Reason string    `json:"reason" validate:"required"`
+}
+
+// ImpersonationGrant is a new session with the token to send, alongside the admin's own
+// bearer token, as the X-Impersonation-Token header
+type ImpersonationGrant struct {
+	Session *ImpersonationSession `json:"session"`
+	Token   string                `json:"token"`
+}
+
+// Audit log actions
+const (
+	AuditActionImpersonationStarted = "impersonation.started"
+	AuditActionImpersonationEnded   = "impersonation.ended"
+	AuditActionImpersonatedRequest  = "impersonation.request"
+)
+
+// AuditLogEntry records something an admin did on a user's behalf
+type AuditLogEntry struct {
+	ID           uuid.UUID              `json:"id"`
+	ActorID      uuid.UUID              `json:"actor_id"`
+	Action       string                 `json:"action"`
+	TargetUserID *uuid.UUID             `json:"target_user_id,omitempty"`
+	SessionID    *uuid.UUID             `json:"session_id,omitempty"`
+	Method       *string                `json:"method,omitempty"`
+	Path         *string                `json:"path,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// Usage flag subjects: a user, or a provider API key sent by requests, identified by a
+// fingerprint of the key
+const (
+	UsageSubjectUser   = "user"
+	UsageSubjectAPIKey = "api_key"
+)
+
+// Usage flag reasons
+const (
+	// UsageFlagAddressEnumeration is many distinct addresses looked up in a short time
+	UsageFlagAddressEnumeration = "address_enumeration"
+	// UsageFlagRequestBurst is a request rate far above what the dashboard makes
+	UsageFlagRequestBurst = "request_burst"
+)
+
+// UsageFlag records API usage that looked like scraping. The subject is limited more
+// strictly until RestrictedUntil, unless an admin resolves the flag first.
+type UsageFlag struct {
+	ID              uuid.UUID              `json:"id"`
+	SubjectType     string                 `json:"subject_type"`
+	Subject         string                 `json:"subject"`
+	UserID          *uuid.UUID             `json:"user_id,omitempty"`
+	Reason          string                 `json:"reason"`
+	Details         map[string]interface{} `json:"details"`
+	RestrictedUntil time.Time              `json:"restricted_until"`
+	ResolvedAt      *time.Time             `json:"resolved_at,omitempty"`
+	ResolvedBy      *uuid.UUID             `json:"resolved_by,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+}
+
+// SecretRotationResult counts the stored secrets re-sealed with the current encryption
+// key
+type SecretRotationResult struct {
+	APIKeys             int `json:"api_keys"`
+	ExchangeAccounts    int `json:"exchange_accounts"`
+	Alerts              int `json:"alerts"`
+	OutboxNotifications int `json:"outbox_notifications"`
+	WebhookReports      int `json:"webhook_reports"`
+}
+
+// VerifyWebhookRequest asks for a webhook URL to be sent a verification challenge
+type VerifyWebhookRequest struct {
+	URL string `json:"url"`
+}
+
+// WebhookVerification records that a user's webhook URL answered the verification
+// challenge, so alerts can be delivered to it
+type WebhookVerification struct {
+	URL        string    `json:"url"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// WebhookChallenge is posted to a webhook URL being verified; the receiver answers
+// with the challenge in the response body, as JSON {"challenge": ...} or as plain text
+type WebhookChallenge struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+}
+
+// WebhookChallengeType is the type of a WebhookChallenge, telling it apart from alert
+// notifications
+const WebhookChallengeType = "webhook_verification"
+
+// UserAPIKey represents a user-supplied provider API key; the key itself is never serialized
+type UserAPIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Provider   string     `json:"provider"`
+	KeyHint    string     `json:"key_hint"`
+	UsageCount int64      `json:"usage_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// API key provider constants
+const (
+	APIKeyProviderAlchemy   = "alchemy"
+	APIKeyProviderCoinGecko = "coingecko"
+	APIKeyProviderEtherscan = "etherscan"
+	APIKeyProviderInfura    = "infura"
+)
+
+// ProviderKeys holds the provider API keys to use for a single request.
+// Empty values fall back to the platform keys inside the client factories.
+type ProviderKeys struct {
+	Alchemy   string
+	CoinGecko string
+	Etherscan string
+	Infura    string
+}
+
+// SetUserAPIKeyRequest represents the request to store a provider API key
+type SetUserAPIKeyRequest struct {
+	APIKey string `json:"api_key" validate:"required"`
+}
+
+
+// ReportPreferences are a user's settings for monthly portfolio statements
+type ReportPreferences struct {
+	UserID         uuid.UUID `json:"user_id"`
+	MonthlyEnabled bool      `json:"monthly_enabled"`
+	EmailEnabled   bool      `json:"email_enabled"` // email a download link when a statement is ready
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// UpdateReportPreferencesRequest represents a partial update of report preferences
+type UpdateReportPreferencesRequest struct {
+	MonthlyEnabled *bool `json:"monthly_enabled,omitempty"`
+	EmailEnabled   *bool `json:"email_enabled,omitempty"`
+}
+
+// ReportStatement is a generated PDF portfolio statement covering [PeriodStart, PeriodEnd)
+type ReportStatement struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	PeriodStart   time.Time  `json:"period_start"`
+	PeriodEnd     time.Time  `json:"period_end"`
+	UploadID      uuid.UUID  `json:"upload_id"`
+	SizeBytes     int64           `json:"size_bytes"`
+	TotalValueUSD decimal.Decimal `json:"total_value_usd"`
+	EmailedAt     *time.Time      `json:"emailed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// GenerateStatementRequest represents a request to generate a statement on demand
+type GenerateStatementRequest struct {
+	Month string `json:"month" validate:"required"` // YYYY-MM
+}
+
+// StatementHolding is one position in a statement, valued when the statement was generated
+type StatementHolding struct {
+	Source   string          `json:"source"` // wallet address, "Bitcoin" or exchange account
+	Symbol   string          `json:"symbol"`
+	Quantity float64         `json:"quantity"`
+	ValueUSD decimal.Decimal `json:"value_usd"`
+}
+
+// StatementPnL is realized PnL over a statement period from one source
+type StatementPnL struct {
+	Source         string          `json:"source"`
+	RealizedPnLUSD decimal.Decimal `json:"realized_pnl_usd"`
+}
+
+// StatementFeeSpend is gas paid on one chain over a statement period
+type StatementFeeSpend struct {
+	ChainID int             `json:"chain_id"`
+	TxCount int             `json:"tx_count"`
+	FeeUSD  decimal.Decimal `json:"fee_usd"`
+}
+
+// StatementIncome is income of one category received over a statement period
+type StatementIncome struct {
+	Category string          `json:"category"`
+	ValueUSD decimal.Decimal `json:"value_usd"`
+}
+
+// StatementData is the content of a portfolio statement
+type StatementData struct {
+	UserID              uuid.UUID           `json:"user_id"`
+	PeriodStart         time.Time           `json:"period_start"`
+	PeriodEnd           time.Time           `json:"period_end"`
+	GeneratedAt         time.Time           `json:"generated_at"`
+	Holdings            []StatementHolding  `json:"holdings"`
+	TotalValueUSD       decimal.Decimal     `json:"total_value_usd"`
+	OpeningValueUSD     *decimal.Decimal    `json:"opening_value_usd,omitempty"` // previous statement's closing value
+	RealizedPnL         []StatementPnL      `json:"realized_pnl"`
+	TotalRealizedPnLUSD decimal.Decimal     `json:"total_realized_pnl_usd"`
+	Fees                []StatementFeeSpend `json:"fees"`
+	TotalFeesUSD        decimal.Decimal     `json:"total_fees_usd"`
+	Income              []StatementIncome   `json:"income"`
+	TotalIncomeUSD      decimal.Decimal     `json:"total_income_usd"`
+}
+
+// Upload kinds
+const (
+	UploadKindExport = "export"
+	UploadKindReport = "report"
+	UploadKindImport = "import"
+)
+
+// Upload represents a file kept in object storage on a user's behalf. Uploads with an
+// expiry are deleted by the retention job once it has passed.
+type Upload struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Kind        string     `json:"kind"`
+	StorageKey  string     `json:"-"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// UploadLink is an upload with a time-limited download URL
+type UploadLink struct {
+	*Upload
+	DownloadURL  string    `json:"download_url"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+}
+
+// Transaction and lot sources
+const (
+	SourceOnchain = "onchain"
+	SourceManual  = "manual"
+)
+
+// Manual transaction types accepted by CSV import
+const (
+	ManualTypeBuy     = "buy"
+	ManualTypeSell    = "sell"
+	ManualTypeGiftIn  = "gift_in"
+	ManualTypeGiftOut = "gift_out"
+	ManualTypeIncome  = "income"
+)
+
+// CSV import fields a column can be mapped to
+const (
+	ImportFieldDate     = "date"
+	ImportFieldType     = "type"
+	ImportFieldAsset    = "asset"
+	ImportFieldQuantity = "quantity"
+	ImportFieldPriceUSD = "price_usd"
+	ImportFieldTotalUSD = "total_usd"
+	ImportFieldFeeUSD   = "fee_usd"
+	ImportFieldNotes    = "notes"
+)
+
+// Transaction import statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusPreviewed = "previewed"
+	ImportStatusCommitted = "committed"
+)
+
+// TransactionImport tracks a CSV of manual transactions through upload, column mapping,
+// preview and commit
+type TransactionImport struct {
+	ID            uuid.UUID         `json:"id"`
+	UserID        uuid.UUID         `json:"user_id"`
+	WalletID      uuid.UUID         `json:"wallet_id"`
+	UploadID      *uuid.UUID        `json:"upload_id,omitempty"`
+	Filename      string            `json:"filename"`
+	Status        string            `json:"status"`
+	Mapping       map[string]string `json:"mapping,omitempty"` // field -> CSV column
+	RowCount      int               `json:"row_count"`
+	ImportedCount int               `json:"imported_count"`
+	CommittedAt   *time.Time        `json:"committed_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// TransactionImportRow is one CSV row parsed with a column mapping. Row is the line
+// number in the file, counting the header as line 1.
+type TransactionImportRow struct {
+	Row       int       `json:"row"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	Asset     string    `json:"asset"`
+	Quantity  float64   `json:"quantity"`
+	PriceUSD  float64   `json:"price_usd"`
+	FeeUSD    float64   `json:"fee_usd"`
+	Notes     string    `json:"notes,omitempty"`
+	// IncomeCategory is set for income rows, e.g. "staking_reward" for a staking row
+	IncomeCategory string   `json:"income_category,omitempty"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// TransactionImportPreview describes an import's columns and, once mapped, its rows
+type TransactionImportPreview struct {
+	Import           *TransactionImport     `json:"import"`
+	Columns          []string               `json:"columns"`
+	SuggestedMapping map[string]string      `json:"suggested_mapping"`
+	Sample           [][]string             `json:"sample,omitempty"`
+	Rows             []TransactionImportRow `json:"rows,omitempty"`
+	ValidCount       int                    `json:"valid_count"`
+	InvalidCount     int                    `json:"invalid_count"`
+}
+
+// PreviewTransactionImportRequest maps import fields to CSV columns
+type PreviewTransactionImportRequest struct {
+	Mapping map[string]string `json:"mapping" validate:"required"`
+}
+
+// CommitTransactionImportRequest represents a request to commit a previewed import
+type CommitTransactionImportRequest struct {
+	// SkipInvalid commits the valid rows when some are invalid, instead of refusing
+	SkipInvalid bool `json:"skip_invalid"`
+}
+
+// CustomChain is an EVM chain added by configuration rather than code, read through its
+// own RPC endpoint
+type CustomChain struct {
+	ChainID           int        `json:"chain_id"`
+	Name              string     `json:"name"`
+	RPCURL            string     `json:"rpc_url"`
+	ExplorerURL       *string    `json:"explorer_url,omitempty"`
+	NativeSymbol      string     `json:"native_symbol"`
+	NativeCoinGeckoID *string    `json:"native_coingecko_id,omitempty"`
+	IsTestnet         bool       `json:"is_testnet"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// RegisterCustomChainRequest represents a request to add a custom EVM chain
+type RegisterCustomChainRequest struct {
+	ChainID           int     `json:"chain_id" validate:"required,gt=0"`
+	Name              string  `json:"name" validate:"required"`
+	RPCURL            string  `json:"rpc_url" validate:"required,url"`
+	ExplorerURL       *string `json:"explorer_url,omitempty" validate:"omitempty,url"`
+	NativeSymbol      string  `json:"native_symbol" validate:"required"`
+	NativeCoinGeckoID *string `json:"native_coingecko_id,omitempty"`
+	IsTestnet         bool    `json:"is_testnet"`
+}
+
+// Quote aggregator providers that provider policies apply to
+const (
+	ProviderLiFi    = "lifi"
+	ProviderSocket  = "socket"
+	ProviderZeroX   = "0x"
+	ProviderOneInch = "1inch"
+	// ProviderAll makes a policy apply to every provider
+	ProviderAll = "*"
+)
+
+// ProviderPolicy is an admin override for a quote provider on one chain, or on every
+// chain when ChainID is nil. Unset limits fall through to broader policies.
+type ProviderPolicy struct {
+	ID          uuid.UUID  `json:"id"`
+	Provider    string     `json:"provider"`
+	ChainID     *int       `json:"chain_id,omitempty"`
+	Disabled    bool       `json:"disabled"`
+	Weight      *float64   `json:"weight,omitempty"`
+	MaxSlippage *float64   `json:"max_slippage,omitempty"`
+	MaxFeeUSD   *float64   `json:"max_fee_usd,omitempty"`
+	Reason      *string    `json:"reason,omitempty"`
+	UpdatedBy   *uuid.UUID `json:"updated_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// UpsertProviderPolicyRequest creates or replaces the policy for a provider and chain
+type UpsertProviderPolicyRequest struct {
+	Provider    string   `json:"provider" validate:"required"`
+	ChainID     *int     `json:"chain_id,omitempty" validate:"omitempty,gt=0"`
+	Disabled    bool     `json:"disabled"`
+	Weight      *float64 `json:"weight,omitempty" validate:"omitempty,gt=0"`
+	MaxSlippage *float64 `json:"max_slippage,omitempty" validate:"omitempty,gt=0"`
+	MaxFeeUSD   *float64 `json:"max_fee_usd,omitempty" validate:"omitempty,gte=0"`
+	Reason      *string  `json:"reason,omitempty"`
+}
+
+// Saved search kinds, named after the list each one filters
+const (
+	SavedSearchKindYieldPools   = "yield_pools"
+	SavedSearchKindTransactions = "transactions"
+)
+
+// SavedSearch is a named filter set for the yield explorer or the transaction list.
+// Params are the query parameters of the matching search endpoint.
+type SavedSearch struct {
+	ID         uuid.UUID         `json:"id"`
+	UserID     uuid.UUID         `json:"user_id"`
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind"`
+	Params     map[string]string `json:"params"`
+	ShareToken *string           `json:"share_token,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// SharedSavedSearch is what a share link reveals of a saved search
+type SharedSavedSearch struct {
+	Name   string            `json:"name"`
+	Kind   string            `json:"kind"`
+	Params map[string]string `json:"params"`
+}
+
+// CreateSavedSearchRequest saves a filter set; Shared creates a share link for it
+type CreateSavedSearchRequest struct {
+	Name   string            `json:"name" validate:"required,max=100"`
+	Kind   string            `json:"kind" validate:"required,oneof=yield_pools transactions"`
+	Params map[string]string `json:"params"`
+	Shared bool              `json:"shared"`
+}
+
+// UpdateSavedSearchRequest changes a saved search; nil fields are left as they are.
+// Setting Shared to false revokes the share link and setting it to true again issues a
+// new one.
+type UpdateSavedSearchRequest struct {
+	Name   *string            `json:"name,omitempty" validate:"omitempty,max=100"`
+	Params *map[string]string `json:"params,omitempty"`
+	Shared *bool              `json:"shared,omitempty"`
+}
+
+// Number formats of custom metrics, for the widgets showing them
+const (
+	MetricFormatUSD     = "usd"
+	MetricFormatPercent = "percent"
+	MetricFormatNumber  = "number"
+)
+
+// CustomMetric is a number the user defines over their portfolio with an expression,
+// like stablecoin_value_usd / total_value_usd * 100, shown as a dashboard widget. Value
+// and Error are from the last evaluation, at ComputedAt; Error is set instead of Value
+// when the expression can't be evaluated, e.g. it divides by a zero total.
+type CustomMetric struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Expression string     `json:"expression"`
+	Format     string     `json:"format"`
+	Position   int        `json:"position"`
+	Value      *float64   `json:"value"`
+	Error      *string    `json:"error,omitempty"`
+	ComputedAt *time.Time `json:"computed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CreateCustomMetricRequest defines a custom metric; Format defaults to number
+type CreateCustomMetricRequest struct {
+	Name       string `json:"name" validate:"required,max=100"`
+	Expression string `json:"expression" validate:"required"`
+	Format     string `json:"format" validate:"omitempty,oneof=usd percent number"`
+	Position   int    `json:"position"`
+}
+
+// UpdateCustomMetricRequest changes a custom metric; nil fields are left as they are
+type UpdateCustomMetricRequest struct {
+	Name       *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Expression *string `json:"expression,omitempty"`
+	Format     *string `json:"format,omitempty" validate:"omitempty,oneof=usd percent number"`
+	Position   *int    `json:"position,omitempty"`
+}
+
+// MetricVariable is a name custom metric expressions can use, a variable or a function
+type MetricVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// MetricVariables lists what custom metric expressions can use
+type MetricVariables struct {
+	Variables []MetricVariable `json:"variables"`
+	Functions []MetricVariable `json:"functions"`
+}
+
+// MetricHolding is one of a user's holdings custom metrics are computed from: a token
+// balance in a wallet, or an active yield position with its pool's APY
+type MetricHolding struct {
+	// Symbol is upper case; a position's is its pool's, like USDC-WETH
+	Symbol   string
+	ValueUSD float64
+	// Stablecoin is set for tokens CoinGecko files as stablecoins and pools of them
+	Stablecoin bool
+	Position   bool
+	APY        *float64
+}
+
+// Sections a webhook report can carry
+const (
+	WebhookReportSectionSummary   = "summary"
+	WebhookReportSectionPositions = "positions"
+	WebhookReportSectionPnL       = "pnl"
+)
+
+// Outcomes of a webhook report's last delivery
+const (
+	WebhookReportDelivered = "delivered"
+	WebhookReportFailed    = "failed"
+)
+
+// WebhookReport posts a report on the user's portfolio to their endpoint on a cron
+// schedule, in UTC. Deliveries are signed with a secret only shown when the report is
+// created; the owner is emailed once deliveries keep failing.
+type WebhookReport struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	Secret   string    `json:"-"`
+	Schedule string    `json:"schedule"`
+	Sections []string  `json:"sections"`
+	Enabled  bool      `json:"enabled"`
+	// NextRunAt is when the report is next posted, if enabled
+	NextRunAt  time.Time  `json:"next_run_at"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	LastStatus *string    `json:"last_status,omitempty"`
+	LastError  *string    `json:"last_error,omitempty"`
+	// ConsecutiveFailures counts the deliveries failed since the last that went through
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	FailureAlerted      bool      `json:"-"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// CreatedWebhookReport is a new webhook report with the secret its deliveries are
+// signed with, which isn't shown again
+type CreatedWebhookReport struct {
+	*WebhookReport
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookReportRequest schedules a webhook report. Schedule is a cron expression
+// with five fields or a descriptor like @daily; Sections default to all of them.
+type CreateWebhookReportRequest struct {
+	Name     string   `json:"name" validate:"required,max=100"`
+	URL      string   `json:"url" validate:"required"`
+	Schedule string   `json:"schedule" validate:"required"`
+	Sections []string `json:"sections"`
+	Enabled  *bool    `json:"enabled,omitempty"`
+}
+
+// UpdateWebhookReportRequest changes a webhook report; nil fields are left as they are
+type UpdateWebhookReportRequest struct {
+	Name     *string  `json:"name,omitempty" validate:"omitempty,max=100"`
+	URL      *string  `json:"url,omitempty"`
+	Schedule *string  `json:"schedule,omitempty"`
+	Sections []string `json:"sections,omitempty"`
+	Enabled  *bool    `json:"enabled,omitempty"`
+}
+
+// WebhookReportDelivery is the outcome of posting a webhook report
+type WebhookReportDelivery struct {
+	DeliveryID  uuid.UUID `json:"delivery_id"`
+	Status      string    `json:"status"`
+	Error       *string   `json:"error,omitempty"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// WebhookReportPayload is the JSON body of a webhook report. Only the report's sections
+// are set; an empty positions section is left out too.
+type WebhookReportPayload struct {
+	ReportID    uuid.UUID               `json:"report_id"`
+	DeliveryID  uuid.UUID               `json:"delivery_id"`
+	Name        string                  `json:"name"`
+	GeneratedAt time.Time               `json:"generated_at"`
+	Summary     *WebhookReportSummary   `json:"summary,omitempty"`
+	Positions   []WebhookReportPosition `json:"positions,omitempty"`
+	PnL         *WebhookReportPnL       `json:"pnl,omitempty"`
+}
+
+// WebhookReportSummary is the value of the user's wallets and positions, with their
+// largest token holdings
+type WebhookReportSummary struct {
+	TotalValueUSD     float64                `json:"total_value_usd"`
+	WalletValueUSD    float64                `json:"wallet_value_usd"`
+	PositionsValueUSD float64                `json:"positions_value_usd"`
+	Wallets           int                    `json:"wallets"`
+	ActivePositions   int                    `json:"active_positions"`
+	TopHoldings       []WebhookReportHolding `json:"top_holdings"`
+}
+
+// WebhookReportHolding is a token's balance summed across the user's wallets on a chain
+type WebhookReportHolding struct {
+	ChainID      int     `json:"chain_id"`
+	TokenAddress string  `json:"token_address"`
+	Symbol       string  `json:"symbol"`
+	Amount       float64 `json:"amount"`
+	ValueUSD     float64 `json:"value_usd"`
+}
+
+// WebhookReportPosition is one of the user's active yield positions
+type WebhookReportPosition struct {
+	ID         uuid.UUID `json:"id"`
+	ChainID    int       `json:"chain_id"`
+	Protocol   string    `json:"protocol,omitempty"`
+	Pool       string    `json:"pool,omitempty"`
+	ValueUSD   *float64  `json:"value_usd,omitempty"`
+	APY        *float64  `json:"apy,omitempty"`
+	PnLUSD     *float64  `json:"pnl_usd,omitempty"`
+	RewardsUSD *float64  `json:"rewards_usd,omitempty"`
+}
+
+// WebhookReportPnL is the profit and loss of the user's yield positions, realized and
+// unrealized, and the rewards they earned
+type WebhookReportPnL struct {
+	TotalPnLUSD        float64 `json:"total_pnl_usd"`
+	TotalPnLPercentage float64 `json:"total_pnl_percentage"`
+	TotalRewardsUSD    float64 `json:"total_rewards_usd"`
+}
+
+// WalletGroup is a named set of a user's wallets, viewed as a portfolio of its own
+type WalletGroup struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	Name        string      `json:"name"`
+	Description *string     `json:"description,omitempty"`
+	WalletIDs   []uuid.UUID `json:"wallet_ids"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// CreateWalletGroupRequest creates a group holding the given wallets, moving them out of
+// any group they were in
+type CreateWalletGroupRequest struct {
+	Name        string      `json:"name" validate:"required,max=100"`
+	Description *string     `json:"description,omitempty"`
+	WalletIDs   []uuid.UUID `json:"wallet_ids"`
+}
+
+// UpdateWalletGroupRequest changes a group; nil fields are left as they are. WalletIDs
+// replaces the group's wallets.
+type UpdateWalletGroupRequest struct {
+	Name        *string      `json:"name,omitempty" validate:"omitempty,max=100"`
+	Description *string      `json:"description,omitempty"`
+	WalletIDs   *[]uuid.UUID `json:"wallet_ids,omitempty"`
+}
+
+// WalletGroupPortfolio is the combined portfolio of a group's wallets
+type WalletGroupPortfolio struct {
+	Group      *WalletGroup                `json:"group"`
+	TotalValue float64                     `json:"total_value"`
+	Wallets    []WalletGroupPortfolioEntry `json:"wallets"`
+}
+
+// WalletGroupPortfolioEntry is one wallet's share of a group portfolio. Wallets whose
+// balances couldn't be read have an error instead of a value.
+type WalletGroupPortfolioEntry struct {
+	WalletID   uuid.UUID `json:"wallet_id"`
+	Address    string    `json:"address"`
+	Label      *string   `json:"label,omitempty"`
+	TotalValue float64   `json:"total_value"`
+	Share      float64   `json:"share"` // of the group's total value, 0-1
+	Error      string    `json:"error,omitempty"`
+	// Blocks are the blocks the wallet's balances reflect, per EVM chain
+	Blocks []*DataBlock `json:"blocks,omitempty"`
+}
+
+// WalletGroupPnL is the PnL of a group's EVM wallets over a period
+type WalletGroupPnL struct {
+	Group            *WalletGroup          `json:"group"`
+	Method           string                `json:"method"`
+	From             time.Time             `json:"from"`
+	To               time.Time             `json:"to"`
+	RealizedPnLUSD   string                `json:"realized_pnl_usd"`
+	UnrealizedPnLUSD string                `json:"unrealized_pnl_usd"`
+	TotalPnLUSD      string                `json:"total_pnl_usd"`
+	Wallets          []WalletGroupPnLEntry `json:"wallets"`
+}
+
+// WalletGroupPnLEntry is one wallet's PnL within a group
+type WalletGroupPnLEntry struct {
+	WalletID         uuid.UUID `json:"wallet_id"`
+	Address          string    `json:"address"`
+	Label            *string   `json:"label,omitempty"`
+	RealizedPnLUSD   string    `json:"realized_pnl_usd"`
+	UnrealizedPnLUSD string    `json:"unrealized_pnl_usd"`
+	TotalPnLUSD      string    `json:"total_pnl_usd"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// Team member roles
+const (
+	TeamRoleOwner  = "owner"
+	TeamRoleMember = "member"
+)
+
+// Team is a group of users sharing alerting. Alerts owned by a member can escalate
+// through the team's policies to the other members.
+type Team struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	// Role is the requesting user's role, when listing their teams
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TeamMember struct {
+	TeamID    uuid.UUID `json:"team_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Address   string    `json:"address"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateTeamRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// AddTeamMemberRequest adds the user with the wallet address to the team
+type AddTeamMemberRequest struct {
+	Address string `json:"address" validate:"required"`
+	Role    string `json:"role,omitempty"`
+}
+
+// EscalationStep is one stage of an escalation policy. Discord, Telegram and webhook
+// steps post to the alert's own destinations; email and in-app steps go to a member,
+// or to whoever is on call when the step is reached.
+type EscalationStep struct {
+	// DelayMinutes is how long after the previous step, or the trigger for the first,
+	// the step waits while the escalation is unacknowledged
+	DelayMinutes int        `json:"delay_minutes"`
+	Channel      string     `json:"channel"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"`
+	OnCall       bool       `json:"on_call,omitempty"`
+}
+
+type EscalationPolicy struct {
+	ID        uuid.UUID        `json:"id"`
+	TeamID    uuid.UUID        `json:"team_id"`
+	Name      string           `json:"name"`
+	Steps     []EscalationStep `json:"steps"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+type EscalationPolicyRequest struct {
+	Name  string           `json:"name" validate:"required,max=100"`
+	Steps []EscalationStep `json:"steps"`
+}
+
+// OnCallShift puts a member on call for the team in [StartsAt, EndsAt)
+type OnCallShift struct {
+	ID        uuid.UUID `json:"id"`
+	TeamID    uuid.UUID `json:"team_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateOnCallShiftRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// Team changes that need a second owner's approval when the team has several owners
+const (
+	TeamActionRemoveMember           = "remove_member"
+	TeamActionUpdateEscalationPolicy = "update_escalation_policy"
+	TeamActionDeleteEscalationPolicy = "delete_escalation_policy"
+)
+
+// Team pending action statuses
+const (
+	TeamActionStatusPending  = "pending"
+	TeamActionStatusApproved = "approved"
+	TeamActionStatusRejected = "rejected"
+	TeamActionStatusExpired  = "expired"
+	// TeamActionStatusFailed marks an approved change that could no longer be applied
+	TeamActionStatusFailed = "failed"
+)
+
+// TeamPendingAction is a destructive change to a team requested by one owner, applied
+// once another owner approves it. TargetID is the member or policy it changes.
+type TeamPendingAction struct {
+	ID          uuid.UUID       `json:"id"`
+	TeamID      uuid.UUID       `json:"team_id"`
+	Action      string          `json:"action"`
+	TargetID    uuid.UUID       `json:"target_id"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	RequestedBy uuid.UUID       `json:"requested_by"`
+	Status      string          `json:"status"`
+	DecidedBy   *uuid.UUID      `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time      `json:"decided_at,omitempty"`
+	Error       *string         `json:"error,omitempty"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// SetEscalationPolicyRequest sets the policy an alert escalates through; nil clears it
+type SetEscalationPolicyRequest struct {
+	PolicyID *uuid.UUID `json:"policy_id"`
+}
+
+// Escalation statuses
+const (
+	EscalationStatusOpen         = "open"
+	EscalationStatusAcknowledged = "acknowledged"
+	EscalationStatusExhausted    = "exhausted"
+)
+
+// AlertEscalation walks one trigger of an alert through its policy's steps until a
+// member acknowledges it or the steps run out
+type AlertEscalation struct {
+	ID             uuid.UUID  `json:"id"`
+	AlertID        uuid.UUID  `json:"alert_id"`
+	HistoryID      uuid.UUID  `json:"history_id"`
+	PolicyID       uuid.UUID  `json:"policy_id"`
+	TeamID         uuid.UUID  `json:"team_id"`
+	Status         string     `json:"status"`
+	NextStep       int        `json:"next_step"`
+	NextStepAt     time.Time  `json:"next_step_at"`
+	LastError      *string    `json:"last_error,omitempty"`
+	AcknowledgedBy *uuid.UUID `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	// TriggeredAt and TriggeredValue are the escalated trigger's
+	TriggeredAt    time.Time              `json:"triggered_at"`
+	TriggeredValue map[string]interface{} `json:"triggered_value,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// LogSettings are the log level and debug sampling applied at runtime by the API and
+// worker, overriding LOG_LEVEL until they expire or are reset
+type LogSettings struct {
+	Level string `json:"level"`
+	// DebugSampleRate keeps one in every n high-volume debug messages, such as per-token
+	// price updates; 1 keeps them all
+	DebugSampleRate int        `json:"debug_sample_rate"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	UpdatedBy       *uuid.UUID `json:"updated_by,omitempty"`
+}
+
+// UpdateLogSettingsRequest changes the runtime log settings; DurationMinutes limits how
+// long they apply, so verbose logging can't be left on by accident
+type UpdateLogSettingsRequest struct {
+	Level           string `json:"level"`
+	DebugSampleRate *int   `json:"debug_sample_rate"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+// RequestCapture is a failed API call kept for debugging, with its bodies redacted
+type RequestCapture struct {
+	ID           int64      `json:"id"`
+	RequestID    string     `json:"request_id,omitempty"`
+	Method       string     `json:"method"`
+	Path         string     `json:"path"`
+	Query        string     `json:"query,omitempty"`
+	Status       int        `json:"status"`
+	Error        string     `json:"error,omitempty"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"`
+	RequestBody  string     `json:"request_body,omitempty"`
+	ResponseBody string     `json:"response_body,omitempty"`
+	LatencyMs    int64      `json:"latency_ms"`
+	CapturedAt   time.Time  `json:"captured_at"`
+}
+
+// RequestCaptureFilter narrows the captures listed; zero values match everything
+type RequestCaptureFilter struct {
+	Status     int
+	PathPrefix string
+	RequestID  string
+	UserID     *uuid.UUID
+	Limit      int
+}
+
+// ProviderCall is a logged call to an upstream provider
+type ProviderCall struct {
+	ID            int64     `json:"id"`
+	RequestID     *string   `json:"request_id,omitempty"`
+	Provider      string    `json:"provider"`
+	Method        string    `json:"method"`
+	Host          string    `json:"host"`
+	Endpoint      string    `json:"endpoint"`
+	Status        *int      `json:"status,omitempty"`
+	DurationMs    int       `json:"duration_ms"`
+	ResponseHash  *string   `json:"response_hash,omitempty"`
+	ResponseBytes *int64    `json:"response_bytes,omitempty"`
+	Error         *string   `json:"error,omitempty"`
+	Source        string    `json:"source"`
+	StartedAt     time.Time `json:"started_at"`
+}
+
+// ProviderCallFilter narrows the provider calls listed; zero values match everything
+type ProviderCallFilter struct {
+	RequestID string
+	Provider  string
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+}
+
+// Provider call sources
+const (
+	ProviderCallSourceAPI    = "api"
+	ProviderCallSourceWorker = "worker"
+)
+
+
+// Alchemy webhook event statuses
+const (
+	AlchemyWebhookEventPending   = "pending"
+	AlchemyWebhookEventProcessed = "processed"
+	AlchemyWebhookEventFailed    = "failed"
+)
+
+// AlchemyWebhookEvent is a queued Alchemy Notify delivery, naming the addresses and
+// blocks of the activity the worker ingests for the wallets involved
+type AlchemyWebhookEvent struct {
+	ID          uuid.UUID  `json:"id"`
+	EventID     string     `json:"event_id"`
+	WebhookID   string     `json:"webhook_id"`
+	Type        string     `json:"type"`
+	ChainID     int        `json:"chain_id"`
+	Addresses   []string   `json:"addresses"`
+	FromBlock   *int64     `json:"from_block,omitempty"`
+	ToBlock     *int64     `json:"to_block,omitempty"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	Error       *string    `json:"error,omitempty"`
+	ReceivedAt  time.Time  `json:"received_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+// DataBlock is the block a chain's balances reflect. Unpinned balances are read at
+// whatever block each provider serves, so BlockNumber is the head seen alongside them
+// and may be a block or two off; pinned balances were all read at BlockNumber.
+type DataBlock struct {
+	ChainID        int       `json:"chain_id"`
+	BlockNumber    int64     `json:"block_number"`
+	BlockHash      string    `json:"block_hash"`
+	BlockTimestamp time.Time `json:"block_timestamp"`
+	Pinned         bool      `json:"pinned"`
+}
+
+// CreateAttestationRequest picks the wallets to attest; all the user's wallets by default
+type CreateAttestationRequest struct {
+	WalletGroupID *uuid.UUID `json:"wallet_group_id,omitempty"`
+}
+
+// JobRun is the latest run of a worker job
+type JobRun struct {
+	JobName        string     `json:"job_name"`
+	LastStartedAt  time.Time  `json:"last_started_at"`
+	LastFinishedAt time.Time  `json:"last_finished_at"`
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	LastDurationMs int        `json:"last_duration_ms"`
+	// LastDegraded is why the latest run, though it succeeded, left work undone
+	LastDegraded *string `json:"last_degraded,omitempty"`
+}
+
+// ProviderHealth counts the logged calls to a provider over a window. Failures are
+// calls that errored, were throttled or got a server error.
+type ProviderHealth struct {
+	Provider string `json:"provider"`
+	Calls    int    `json:"calls"`
+	Failures int    `json:"failures"`
+}
+
+// System status levels
+const (
+	SystemStatusOperational = "operational"
+	SystemStatusDegraded    = "degraded"
+	SystemStatusOutage      = "outage"
+	// SystemStatusUnknown is reported when there's nothing to judge by, such as a job
+	// that hasn't run since the status endpoint was deployed
+	SystemStatusUnknown = "unknown"
+)
+
+// SystemStatus is the machine-readable health of the service for status pages. Status
+// is the worst of the subsystems'.
+type SystemStatus struct {
+	Status     string            `json:"status"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Subsystems []SubsystemStatus `json:"subsystems"`
+	// Banner is set while something is degraded, in the shape of a system banner
+	Banner *StatusBanner `json:"banner,omitempty"`
+}
+
+// SubsystemStatus is the health of one area of the service, judged by how old its data
+// is, whether the jobs producing it succeed and how its providers respond
+type SubsystemStatus struct {
+	Name           string           `json:"name"`
+	Status         string           `json:"status"`
+	Message        string           `json:"message,omitempty"`
+	DataUpdatedAt  *time.Time       `json:"data_updated_at,omitempty"`
+	DataAgeSeconds *int64           `json:"data_age_seconds,omitempty"`
+	Jobs           []JobStatus      `json:"jobs"`
+	Providers      []ProviderStatus `json:"providers"`
+}
+
+// JobStatus is a worker job's health. Errors themselves aren't exposed.
+type JobStatus struct {
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastRunFailed bool       `json:"last_run_failed"`
+	// Degraded is why the job's latest run left work undone, if it did
+	Degraded *string `json:"degraded,omitempty"`
+}
+
+type ProviderStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Calls     int     `json:"calls"`
+	Failures  int     `json:"failures"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// StatusBanner is a degradation notice the frontend shows like its system banners
+type StatusBanner struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Message     string `json:"message"`
+	Type        string `json:"type"`
+	Dismissible bool   `json:"dismissible"`
+}
+
+// AllowanceCheck is one allowance read on chain and compared with what was stored
+type AllowanceCheck struct {
+	TokenAddress   string `json:"token_address"`
+	SpenderAddress string `json:"spender_address"`
+	// Allowance is the live allowance, nil if it couldn't be read, e.g. from a
+	// contract that isn't an ERC-20 token
+	Allowance *string `json:"allowance"`
+	// StoredAllowance is what was stored before the check, nil if nothing was
+	StoredAllowance *string `json:"stored_allowance"`
+	// Changed is set when the chain no longer matches the stored allowance
+	Changed bool `json:"changed"`
+	// StoredUpdatedAt and LastVerifiedAt are when the stored row was last written and
+	// last checked before this check
+	StoredUpdatedAt *time.Time `json:"stored_updated_at,omitempty"`
+	LastVerifiedAt  *time.Time `json:"last_verified_at,omitempty"`
+	// Stored is set when the stored row now holds the live allowance. Allowances of
+	// tokens the platform doesn't know, and zero allowances never stored, aren't kept.
+	Stored bool `json:"stored"`
+	// SpenderContract is what the explorer knows of the spender, nil until it's looked up
+	SpenderContract *ContractInfo `json:"spender_contract,omitempty"`
+}
+
+// AllowanceVerification is the result of checking a wallet's allowances on chain
+type AllowanceVerification struct {
+	Wallet     string           `json:"wallet"`
+	ChainID    int              `json:"chain_id"`
+	VerifiedAt time.Time        `json:"verified_at"`
+	Checks     []AllowanceCheck `json:"checks"`
+}
+
+// WalletHolding is a wallet's balance of one token, as snapshotted or as currently stored
+type WalletHolding struct {
+	WalletID     uuid.UUID `json:"wallet_id"`
+	ChainID      int       `json:"chain_id"`
+	TokenAddress string    `json:"token_address"`
+	Symbol       string    `json:"symbol"`
+	// Balance is in the token's base units; Amount is in whole tokens
+	Balance  string   `json:"balance"`
+	Amount   float64  `json:"amount"`
+	PriceUSD *float64 `json:"price_usd,omitempty"`
+	ValueUSD float64  `json:"value_usd"`
+}
+
+// HoldingChange is how a token holding changed between a snapshot and now. The value
+// change splits into the quantity effect, the change in amount at the earlier price,
+// and the price effect, the change in price on the current amount.
+type HoldingChange struct {
+	ChainID           int      `json:"chain_id"`
+	TokenAddress      string   `json:"token_address"`
+	Symbol            string   `json:"symbol"`
+	BalanceBefore     string   `json:"balance_before"`
+	BalanceAfter      string   `json:"balance_after"`
+	AmountChange      float64  `json:"amount_change"`
+	PriceBeforeUSD    *float64 `json:"price_before_usd,omitempty"`
+	PriceAfterUSD     *float64 `json:"price_after_usd,omitempty"`
+	ValueBeforeUSD    float64  `json:"value_before_usd"`
+	ValueAfterUSD     float64  `json:"value_after_usd"`
+	ValueChangeUSD    float64  `json:"value_change_usd"`
+	QuantityEffectUSD float64  `json:"quantity_effect_usd"`
+	PriceEffectUSD    float64  `json:"price_effect_usd"`
+}
+
+// TriggeredAlert is one trigger of a user's alert
+type TriggeredAlert struct {
+	AlertID        uuid.UUID              `json:"alert_id"`
+	Type           string                 `json:"type"`
+	Target         AlertTarget            `json:"target"`
+	TriggeredAt    time.Time              `json:"triggered_at"`
+	TriggeredValue map[string]interface{} `json:"triggered_value"`
+}
+
+// PortfolioDiff is what changed in a wallet since a holdings snapshot
+type PortfolioDiff struct {
+	Address string `json:"address"`
+	ChainID *int   `json:"chain_id,omitempty"`
+	// Since is the requested start; BaselineAt is the snapshot compared against, the
+	// latest one taken by then, or nil when there is none
+	Since             time.Time       `json:"since"`
+	BaselineAt        *time.Time      `json:"baseline_at"`
+	ValueBeforeUSD    float64         `json:"value_before_usd"`
+	ValueAfterUSD     float64         `json:"value_after_usd"`
+	ValueChangeUSD    float64         `json:"value_change_usd"`
+	QuantityEffectUSD float64         `json:"quantity_effect_usd"`
+	PriceEffectUSD    float64         `json:"price_effect_usd"`
+	TokensAdded       []HoldingChange `json:"tokens_added"`
+	TokensRemoved     []HoldingChange `json:"tokens_removed"`
+	BalanceChanges    []HoldingChange `json:"balance_changes"`
+	// PriceChanges are tokens held throughout whose value moved with the price only
+	PriceChanges    []HoldingChange       `json:"price_changes"`
+	NewPositions    []*DerivativePosition `json:"new_positions"`
+	TriggeredAlerts []TriggeredAlert      `json:"triggered_alerts"`
+}
+
+// Onboarding steps, in the order the guided setup walks through them
+const (
+	OnboardingStepWalletAdded   = "wallet_added"
+	OnboardingStepFirstSync     = "first_sync_complete"
+	OnboardingStepFirstAlert    = "first_alert_created"
+	OnboardingStepEmailVerified = "email_verified"
+)
+
+// Onboarding step states. A step is locked until the steps it needs are completed, and
+// completed steps stay completed.
+const (
+	OnboardingStepLocked    = "locked"
+	OnboardingStepPending   = "pending"
+	OnboardingStepCompleted = "completed"
+)
+
+// Onboarding progress statuses
+const (
+	OnboardingNotStarted = "not_started"
+	OnboardingInProgress = "in_progress"
+	OnboardingCompleted  = "completed"
+)
+
+// OnboardingStep is one step of the guided setup with its state
+type OnboardingStep struct {
+	Step        string     `json:"step"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// Hint tells the user how to complete the step; completed steps have none
+	Hint string `json:"hint,omitempty"`
+}
+
+// OnboardingProgress is how far a user is through the guided setup
+type OnboardingProgress struct {
+	Status    string `json:"status"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+	// NextStep is the first pending step, nil once every step is completed
+	NextStep *OnboardingStep  `json:"next_step"`
+	Steps    []OnboardingStep `json:"steps"`
+}
+
+// Plan tiers
+const (
+	PlanTierFree = "free"
+	PlanTierPro  = "pro"
+)
+
+// Resources limited by a user's plan
+const (
+	QuotaWallets          = "wallets"
+	QuotaAlerts           = "alerts"
+	QuotaWatchedAddresses = "watched_addresses"
+	QuotaExportsPerDay    = "exports_per_day"
+	QuotaWebhookChannels  = "webhook_channels"
+)
+
+// UserPlan is a user's plan tier with any limits an admin overrode for them
+type UserPlan struct {
+	UserID         uuid.UUID      `json:"user_id"`
+	Tier           string         `json:"tier"`
+	LimitOverrides map[string]int `json:"limit_overrides"`
+	Note           *string        `json:"note,omitempty"`
+	UpdatedBy      *uuid.UUID     `json:"updated_by,omitempty"`
+	UpdatedAt      *time.Time     `json:"updated_at,omitempty"`
+	// TrialEndsAt is when a pro trial granted to the user, e.g. as a referral reward, ends
+	TrialEndsAt *time.Time `json:"trial_ends_at,omitempty"`
+}
+
+// EffectiveTier is the tier whose limits apply at now: pro during a trial, the plan's
+// own tier otherwise
+func (p *UserPlan) EffectiveTier(now time.Time) string {
+	if p.TrialEndsAt != nil && now.Before(*p.TrialEndsAt) {
+		return PlanTierPro
+	}
+	return p.Tier
+}
+
+// QuotaUsage is how much of one plan-limited resource a user has used
+type QuotaUsage struct {
+	Resource string `json:"resource"`
+	Limit    int    `json:"limit"`
+	Used     int    `json:"used"`
+	// Overridden is whether the limit was set by an admin rather than the plan
+	Overridden bool `json:"overridden"`
+	// Exceeded is whether the user is at or over the limit and can't add more
+	Exceeded bool `json:"exceeded"`
+}
+
+// UserQuota is a user's plan with their usage of each limited resource
+type UserQuota struct {
+	Tier   string       `json:"tier"`
+	Quotas []QuotaUsage `json:"quotas"`
+	// TrialEndsAt is set while the user is on a pro trial, which Tier reflects
+	TrialEndsAt *time.Time `json:"trial_ends_at,omitempty"`
+}
+
+// SetUserPlanRequest changes a user's plan. Fields left out are kept; limit_overrides
+// replaces the user's overrides, and an empty object clears them.
+type SetUserPlanRequest struct {
+	Tier           *string        `json:"tier"`
+	LimitOverrides map[string]int `json:"limit_overrides"`
+	Note           *string        `json:"note"`
+}
+
+// Stripe subscription statuses
+const (
+	SubscriptionActive     = "active"
+	SubscriptionTrialing   = "trialing"
+	SubscriptionPastDue    = "past_due"
+	SubscriptionUnpaid     = "unpaid"
+	SubscriptionCanceled   = "canceled"
+	SubscriptionIncomplete = "incomplete"
+)
+
+// BillingAccount ties a user to their Stripe customer and subscription
+type BillingAccount struct {
+	UserID               uuid.UUID  `json:"-"`
+	StripeCustomerID     string     `json:"-"`
+	StripeSubscriptionID *string    `json:"-"`
+	Status               string     `json:"status"`
+	CurrentPeriodEnd     *time.Time `json:"current_period_end,omitempty"`
+	CancelAtPeriodEnd    bool       `json:"cancel_at_period_end"`
+	// GraceUntil is set while a failed renewal is retried; the user keeps the pro tier
+	// until then
+	GraceUntil *time.Time `json:"grace_until,omitempty"`
+	// LastEventAt is when the latest Stripe event applied to the account was created
+	LastEventAt *time.Time `json:"-"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BillingStatus is a user's plan tier with their subscription, nil if they never
+// subscribed
+type BillingStatus struct {
+	Tier         string          `json:"tier"`
+	Subscription *BillingAccount `json:"subscription"`
+}
+
+// BillingInvoice is an invoice of a user's subscription. Amounts are in the currency's
+// smallest unit.
+type BillingInvoice struct {
+	ID               string     `json:"id"`
+	UserID           uuid.UUID  `json:"-"`
+	Number           *string    `json:"number,omitempty"`
+	Status           string     `json:"status"`
+	AmountDue        int64      `json:"amount_due"`
+	AmountPaid       int64      `json:"amount_paid"`
+	Currency         string     `json:"currency"`
+	HostedInvoiceURL *string    `json:"hosted_invoice_url,omitempty"`
+	InvoicePDF       *string    `json:"invoice_pdf,omitempty"`
+	PeriodStart      *time.Time `json:"period_start,omitempty"`
+	PeriodEnd        *time.Time `json:"period_end,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// BillingCheckout is a Stripe checkout page for subscribing to the pro tier
+type BillingCheckout struct {
+	SessionID string `json:"session_id"`
+	URL       string `json:"url"`
+}
+
+// Referral statuses
+const (
+	ReferralRewarded = "rewarded"
+	ReferralRejected = "rejected"
+)
+
+// Reasons a referral was rejected
+const (
+	ReferralRejectSelf       = "self_referral"
+	ReferralRejectSameWallet = "same_wallet"
+	ReferralRejectSameEmail  = "same_email"
+)
+
+// Referral is a user who signed up with another's referral code
+type Referral struct {
+	ID         uuid.UUID `json:"id"`
+	ReferrerID uuid.UUID `json:"-"`
+	ReferredID uuid.UUID `json:"-"`
+	Code       string    `json:"code"`
+	Status     string    `json:"status"`
+	// RejectReason says why a rejected referral wasn't rewarded
+	RejectReason *string `json:"reject_reason,omitempty"`
+	// ReferrerRewardDays and ReferredRewardDays are the pro trial days each side got
+	ReferrerRewardDays int       `json:"referrer_reward_days"`
+	ReferredRewardDays int       `json:"referred_reward_days"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// ReferralStats are a user's referral code with how it has done
+type ReferralStats struct {
+	Code     string `json:"code"`
+	Referred int    `json:"referred"`
+	// Rewarded counts the referrals that earned the user reward days
+	Rewarded int `json:"rewarded"`
+	Rejected int `json:"rejected"`
+	// RewardDays are the pro trial days the user earned by referring others
+	RewardDays int `json:"reward_days"`
+	// RewardsLeft is how many more referrals will be rewarded
+	RewardsLeft int        `json:"rewards_left"`
+	Recent      []Referral `json:"recent"`
+}
+
+// ClaimReferralRequest attributes the user's signup to a referral code
+type ClaimReferralRequest struct {
+	Code string `json:"code"`
+}
+
+// Usage analytics events
+const (
+	AnalyticsWalletAdded    = "wallet_added"
+	AnalyticsQuoteRequested = "quote_requested"
+	AnalyticsAlertTriggered = "alert_triggered"
+)
+
+// AnalyticsSettings say whether the user's usage is counted in analytics
+type AnalyticsSettings struct {
+	OptedOut   bool       `json:"opted_out"`
+	OptedOutAt *time.Time `json:"opted_out_at,omitempty"`
+}
+
+// UpdateAnalyticsSettingsRequest turns usage analytics off or back on for the user
+type UpdateAnalyticsSettingsRequest struct {
+	OptedOut bool `json:"opted_out"`
+}
+
+// AnalyticsExperimentExposure is the usage event of a user being shown an experiment's
+// variant
+const AnalyticsExperimentExposure = "experiment_exposure"
+
+// ExperimentControl is the variant everyone gets while an experiment is off
+const ExperimentControl = "control"
+
+// ExperimentAssignment is the variant of an experiment a user gets
+type ExperimentAssignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	// Active is false while the experiment is off, when everyone gets the control
+	Active bool `json:"active"`
+}
+
+// ExperimentExposure records the variant a user was first shown
+type ExperimentExposure struct {
+	Experiment string    `json:"experiment"`
+	UserID     uuid.UUID `json:"-"`
+	Variant    string    `json:"variant"`
+	ExposedAt  time.Time `json:"exposed_at"`
+}
+
+// ExperimentResults summarize how each variant of an experiment converts
+type ExperimentResults struct {
+	Experiment string                    `json:"experiment"`
+	Active     bool                      `json:"active"`
+	Variants   []ExperimentVariantResult `json:"variants"`
+}
+
+// ExperimentVariantResult is one variant's exposures and conversions
+type ExperimentVariantResult struct {
+	Variant string `json:"variant"`
+	// Weight is the variant's current share of assignments, 0 for variants no longer
+	// configured
+	Weight      int                    `json:"weight"`
+	Exposures   int                    `json:"exposures"`
+	Conversions []ExperimentConversion `json:"conversions"`
+}
+
+// ExperimentConversion counts the exposed users who went on to a tracked action
+type ExperimentConversion struct {
+	Metric string `json:"metric"`
+	Users  int    `json:"users"`
+	// Rate is Users over the variant's exposures
+	Rate float64 `json:"rate"`
+}
+
+// JobGraph is the worker's scheduled jobs in dependency order
+type JobGraph struct {
+	Jobs []JobGraphNode `json:"jobs"`
+}
+
+// JobGraphNode is a scheduled job, the jobs it runs after and how it last ran
+type JobGraphNode struct {
+	Name        string `json:"name"`
+	Schedule    string `json:"schedule"`
+	Description string `json:"description"`
+	// DependsOn are the jobs that run first when due at the same time; the job is skipped
+	// when one of them fails
+	DependsOn  []string  `json:"depends_on"`
+	Dependents []string  `json:"dependents"`
+	NextRunAt  time.Time `json:"next_run_at"`
+	// LastRun is nil for jobs that haven't run, including ones the worker doesn't
+	// schedule because their integration isn't configured
+	LastRun *JobRun `json:"last_run,omitempty"`
+}
+// Screening list categories. Sanctions lists hold addresses of designated parties, mixer
+// lists those of mixing services.
+const (
+	ScreeningCategorySanctions = "sanctions"
+	ScreeningCategoryMixer     = "mixer"
+)
+
+// Wallet exposure levels to screened addresses, least severe first
+const (
+	ExposureNone   = "none"
+	ExposureLow    = "low"
+	ExposureMedium = "medium"
+	ExposureHigh   = "high"
+)
+
+// Directions of a wallet's transactions with a screened address
+const (
+	ExposureSent     = "sent"
+	ExposureReceived = "received"
+)
+
+// ScreeningList is a list of sanctioned or mixer addresses counterparties are screened
+// against
+type ScreeningList struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Category     string    `json:"category"`
+	AddressCount int       `json:"address_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateScreeningListRequest adds a screening list with its addresses
+type CreateScreeningListRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Category  string   `json:"category" validate:"required,oneof=sanctions mixer"`
+	Addresses []string `json:"addresses"`
+}
+
+// SetScreeningListAddressesRequest replaces a screening list's addresses
+type SetScreeningListAddressesRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// ExposureHit is a wallet's transactions in one direction with a screened address
+type ExposureHit struct {
+	WalletID  uuid.UUID `json:"-"`
+	Address   string    `json:"address"`
+	List      string    `json:"list"`
+	Category  string    `json:"category"`
+	Direction string    `json:"direction"`
+	TxCount   int       `json:"tx_count"`
+	LastAt    time.Time `json:"last_at"`
+}
+
+// WalletExposure is how exposed a wallet is to screened addresses, with the dealings behind it
+type WalletExposure struct {
+	WalletID   uuid.UUID     `json:"wallet_id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	Address    string        `json:"address"`
+	ChainID    int           `json:"chain_id"`
+	Level      string        `json:"level"`
+	Hits       []ExposureHit `json:"hits"`
+	ScreenedAt time.Time     `json:"screened_at"`
+}
+
+// ExposureReport is the screening of a user's wallets. Level is the highest of their
+// wallets'; wallets without exposure aren't listed.
+type ExposureReport struct {
+	Level   string            `json:"level"`
+	Wallets []*WalletExposure `json:"wallets"`
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAsset(t *testing.T) {
+	cosmosHub := ChainRef{Namespace: ChainNamespaceCosmos, Reference: "cosmoshub-4"}
+
+	tests := []struct {
+		name  string
+		token Token
+		want  string
+		class string
+	}{
+		{"ether", Token{Address: evmZeroAddress, ChainID: 1}, "eip155:1/slip44:60", AssetClassNative},
+		{"polygon native", Token{Address: evmZeroAddress, ChainID: 137}, "eip155:137/slip44:966", AssetClassNative},
+		{"erc20", Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", ChainID: 1}, "eip155:1/erc20:0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", AssetClassFungible},
+		{"bitcoin row", Token{Address: BTCTokenAddress}, "bip122:000000000019d6689c085ae165831e93/slip44:0", AssetClassNative},
+		{"atom", Token{Address: "uatom", Chain: &cosmosHub}, "cosmos:cosmoshub-4/slip44:118", AssetClassNative},
+		{"ibc denom", Token{Address: "ibc/27394FB092D2ECCD", Chain: &cosmosHub}, "cosmos:cosmoshub-4/denom:ibc%2F27394FB092D2ECCD", AssetClassFungible},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset := tt.token.Asset()
+			assert.Equal(t, tt.want, asset.ID.String())
+			assert.Equal(t, tt.class, asset.Class)
+		})
+	}
+}
+
+func TestAssetRefJSON(t *testing.T) {
+	nft := NFTAsset(1, "0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D", "42", "erc721")
+
+	data, err := json.Marshal(nft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `"eip155:1/erc721:0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d/42"`, string(data))
+
+	var parsed AssetRef
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, nft.ID, parsed)
+
+	for _, invalid := range []string{"eip155:1", "eip155:1/erc20", "eip155:1/erc20:0xa/", "a/b/c/d"} {
+		_, err := ParseAssetRef(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestAlertTargetResolveAsset(t *testing.T) {
+	usdc, err := ParseAssetRef("eip155:137/erc20:0x3C499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	require.NoError(t, err)
+	target := AlertTarget{Asset: &usdc}
+	require.NoError(t, target.ResolveAsset())
+	assert.Equal(t, "token", target.Type)
+	assert.Equal(t, "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359", target.Identifier)
+	assert.Equal(t, 137, target.ChainID)
+
+	btc := AlertTarget{Type: "token", Identifier: BTCTokenAddress}
+	require.NoError(t, btc.ResolveAsset())
+	require.NotNil(t, btc.Asset)
+	assert.Equal(t, BitcoinChain, btc.Asset.Chain)
+
+	offChain := ExchangeAsset("kraken", "btc").ID
+	assert.Error(t, (&AlertTarget{Asset: &offChain}).ResolveAsset())
+
+	wallet := AlertTarget{Type: "address", Identifier: "0xabc", ChainID: 1}
+	require.NoError(t, wallet.ResolveAsset())
+	assert.Nil(t, wallet.Asset)
+}
+
+func TestAddressJSONIsChecksummed(t *testing.T) {
+	wallet := Wallet{Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ChainID: 1}
+	data, err := json.Marshal(wallet)
+	require.NoError(t, err)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", out["address"])
+	assert.Equal(t, float64(1), out["chain_id"])
+	// The stored form is untouched
+	assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", wallet.Address)
+
+	data, err = json.Marshal(&Token{Address: "uatom"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"address":"uatom"`)
+
+	data, err = json.Marshal(User{Address: "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"address":"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"`)
+	assert.NotContains(t, string(data), "nonce")
+}
+
+func TestYieldPoolRewardAPRs(t *testing.T) {
+	var metadata interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"rewards":[{"token":"0xabc","apr":1.25,"source":"defillama"},{"token":"0xdef","source":"defillama"}],"feeTier":3000}`), &metadata))
+
+	rewards := (&YieldPool{Metadata: metadata}).RewardAPRs()
+	require.Len(t, rewards, 2)
+	assert.Equal(t, "0xabc", rewards[0].Token)
+	require.NotNil(t, rewards[0].APR)
+	assert.Equal(t, 1.25, *rewards[0].APR)
+	assert.Nil(t, rewards[1].APR)
+
+	assert.Nil(t, (&YieldPool{}).RewardAPRs())
+	assert.Nil(t, (&YieldPool{Metadata: map[string]interface{}{"rewards": "bad"}}).RewardAPRs())
+}
+
+func TestRewardLockUnlockedAt(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cliff := start.AddDate(0, 0, 25)
+	vesting := &RewardLock{Kind: RewardLockKindVesting, Amount: 100, StartsAt: &start, CliffAt: &cliff, UnlocksAt: start.AddDate(0, 0, 100)}
+
+	assert.Zero(t, vesting.UnlockedAt(start.AddDate(0, 0, 10)), "nothing before the cliff")
+	assert.InDelta(t, 25, vesting.UnlockedAt(cliff), 1e-9)
+	assert.InDelta(t, 50, vesting.UnlockedAt(start.AddDate(0, 0, 50)), 1e-9)
+	assert.Equal(t, 100.0, vesting.UnlockedAt(start.AddDate(1, 0, 0)))
+	assert.Equal(t, &cliff, vesting.NextUnlock(start))
+	assert.Equal(t, vesting.UnlocksAt, *vesting.NextUnlock(cliff))
+	assert.Nil(t, vesting.NextUnlock(vesting.UnlocksAt))
+
+	escrow := &RewardLock{Kind: RewardLockKindVoteEscrow, Amount: 100, UnlocksAt: start}
+	assert.Zero(t, escrow.UnlockedAt(start.Add(-time.Second)))
+	assert.Equal(t, 100.0, escrow.UnlockedAt(start))
+}
+
+func TestWalletBackfillImportedPercent(t *testing.T) {
+	target := int64(199)
+	assert.Zero(t, (&WalletBackfill{Status: BackfillStatusPending}).ImportedPercent(), "not started")
+	assert.Equal(t, 50.0, (&WalletBackfill{Status: BackfillStatusRunning, TargetBlock: &target, NextBlock: 100}).ImportedPercent())
+	assert.Equal(t, 100.0, (&WalletBackfill{Status: BackfillStatusRunning, TargetBlock: &target, NextBlock: 250}).ImportedPercent())
+	assert.Equal(t, 100.0, (&WalletBackfill{Status: BackfillStatusCompleted}).ImportedPercent())
+}
+
+func TestBalanceRefreshDonePercent(t *testing.T) {
+	assert.Zero(t, (&BalanceRefresh{Status: BalanceRefreshPending, Total: 40}).DonePercent())
+	assert.Equal(t, 50.0, (&BalanceRefresh{Status: BalanceRefreshRunning, Total: 40, Refreshed: 15, Failed: 5}).DonePercent())
+	// Wallets added after the refresh was queued can take it past its total
+	assert.Equal(t, 100.0, (&BalanceRefresh{Status: BalanceRefreshRunning, Total: 40, Refreshed: 42}).DonePercent())
+	assert.Equal(t, 100.0, (&BalanceRefresh{Status: BalanceRefreshPending}).DonePercent(), "no wallets")
+}
+
+func TestSharedWallets(t *testing.T) {
+	visible := &Wallet{Address: "0xa", Visibility: WalletVisibilityVisible}
+	private := &Wallet{Address: "0xb", Visibility: WalletVisibilityPrivate}
+	unset := &Wallet{Address: "0xc"}
+	assert.Equal(t, []*Wallet{visible, unset}, SharedWallets([]*Wallet{visible, private, unset}))
+	assert.Empty(t, SharedWallets([]*Wallet{private}))
+}
+
+func TestWalletIsContractAccount(t *testing.T) {
+	accountType := func(s string) *string { return &s }
+	assert.False(t, (&Wallet{}).IsContractAccount(), "undetected wallets sign as EOAs")
+	assert.False(t, (&Wallet{AccountType: accountType(WalletAccountEOA)}).IsContractAccount())
+	for _, contract := range []string{WalletAccountSafe, WalletAccountERC4337, WalletAccountContract} {
+		assert.True(t, (&Wallet{AccountType: accountType(contract)}).IsContractAccount(), contract)
+	}
+}
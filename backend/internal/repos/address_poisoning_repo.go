@@ -0,0 +1,115 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AddressPoisoningRepository serves the lookalike counterparties flagged for wallets.
+// Transfers are flagged by the wallet backfill repository as it stores the wallet's history.
+type AddressPoisoningRepository interface {
+	// GetByWallet returns the wallet's poisoned counterparties, most recently seen first
+	GetByWallet(ctx context.Context, walletID uuid.UUID) ([]*models.PoisonedCounterparty, error)
+}
+
+type addressPoisoningRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAddressPoisoningRepository(db *pgxpool.Pool) AddressPoisoningRepository {
+	return &addressPoisoningRepository{db: db}
+}
+
+// flagAddressPoisoning stores the wallet's transfers suspected of address poisoning
+// among those just linked to it as part of tx, returning the transactions left to analytics.
+// Known counterparties are those the wallet moved value with, including in the transfers
+// just stored, the owner's other wallets and the addresses they labelled.
+func flagAddressPoisoning(ctx context.Context, tx pgx.Tx, userID, walletID uuid.UUID, walletAddress string, transactions []*models.Transaction) ([]*models.Transaction, error) {
+	if len(transactions) == 0 {
+		return transactions, nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT CASE WHEN lower(t.from_address) = lower($3)
+		                     THEN lower(t.to_address)
+		                     ELSE lower(t.from_address)
+		                END
+		FROM user_transactions ut
+		JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+		WHERE ut.wallet_id = $2 AND t.value > 0 AND t.poisoning_lookalike_of IS NULL
+		  AND t.to_address IS NOT NULL
+		UNION
+		SELECT lower(address) FROM wallets WHERE user_id = $1 AND removed_at IS NULL
+		UNION
+		SELECT address FROM address_labels WHERE user_id = $1`,
+		userID, walletID, walletAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet counterparties: %w", err)
+	}
+	known, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wallet counterparty: %w", err)
+	}
+
+	suspects := blockchain.DetectAddressPoisoning(walletAddress, known, transactions)
+	flagged := make(map[*models.Transaction]bool, len(suspects))
+	for _, s := range suspects {
+		t := s.Transaction
+		if _, err := tx.Exec(ctx, `
+			UPDATE transactions SET poisoning_lookalike_of = $3
+			WHERE hash = $1 AND chain_id = $2`,
+			strings.ToLower(t.Hash), t.ChainID, s.LookalikeOf); err != nil {
+			return nil, fmt.Errorf("failed to flag transaction %s: %w", t.Hash, err)
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO poisoned_counterparties (wallet_id, address, lookalike_of, transaction_count, first_seen_at, last_seen_at)
+			VALUES ($1, $2, $3, 1, $4, $4)
+			ON CONFLICT (wallet_id, address) DO UPDATE SET
+				transaction_count = poisoned_counterparties.transaction_count + 1,
+				first_seen_at = LEAST(poisoned_counterparties.first_seen_at, EXCLUDED.first_seen_at),
+				last_seen_at = GREATEST(poisoned_counterparties.last_seen_at, EXCLUDED.last_seen_at)`,
+			walletID, s.Counterparty, s.LookalikeOf, t.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to flag counterparty %s: %w", s.Counterparty, err)
+		}
+		lookalikeOf := s.LookalikeOf
+		t.PoisoningLookalikeOf = &lookalikeOf
+		flagged[t] = true
+	}
+
+	kept := make([]*models.Transaction, 0, len(transactions)-len(flagged))
+	for _, t := range transactions {
+		if !flagged[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept, nil
+}
+
+func (r *addressPoisoningRepository) GetByWallet(ctx context.Context, walletID uuid.UUID) ([]*models.PoisonedCounterparty, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT wallet_id, address, lookalike_of, transaction_count, first_seen_at, last_seen_at
+		FROM poisoned_counterparties
+		WHERE wallet_id = $1
+		ORDER BY last_seen_at DESC, address`, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poisoned counterparties: %w", err)
+	}
+	defer rows.Close()
+
+	counterparties := []*models.PoisonedCounterparty{}
+	for rows.Next() {
+		var c models.PoisonedCounterparty
+		if err := rows.Scan(&c.WalletID, &c.Address, &c.LookalikeOf, &c.TransactionCount, &c.FirstSeenAt, &c.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("failed to scan poisoned counterparty: %w", err)
+		}
+		counterparties = append(counterparties, &c)
+	}
+	return counterparties, rows.Err()
+}
@@ -0,0 +1,184 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AlchemyWebhookRepository interface {
+	// InsertEvent queues a delivery, reporting false when the event was already queued
+	InsertEvent(ctx context.Context, event *models.AlchemyWebhookEvent) (bool, error)
+	// GetPending lists events waiting to be processed, oldest first
+	GetPending(ctx context.Context, limit int) ([]*models.AlchemyWebhookEvent, error)
+	// MarkProcessed completes an event
+	MarkProcessed(ctx context.Context, id uuid.UUID) error
+	// RecordError counts a failed attempt, failing the event after maxAttempts
+	RecordError(ctx context.Context, id uuid.UUID, message string, maxAttempts int) error
+	// DeleteBefore prunes events received before the time that are no longer pending
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// GetWatchedAddresses returns the EVM wallet addresses on the chain, which its
+	// webhook should watch
+	GetWatchedAddresses(ctx context.Context, chainID int) ([]string, error)
+	// GetSubscribedAddresses returns the addresses the webhook has been told to watch
+	GetSubscribedAddresses(ctx context.Context, webhookID string) ([]string, error)
+	// SetSubscribed records addresses added to and removed from the webhook
+	SetSubscribed(ctx context.Context, webhookID string, chainID int, added, removed []string) error
+}
+
+type alchemyWebhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAlchemyWebhookRepository(db *pgxpool.Pool) AlchemyWebhookRepository {
+	return &alchemyWebhookRepository{db: db}
+}
+
+func (r *alchemyWebhookRepository) InsertEvent(ctx context.Context, event *models.AlchemyWebhookEvent) (bool, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO alchemy_webhook_events (event_id, webhook_id, type, chain_id, addresses, from_block, to_block)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (event_id) DO NOTHING
+		RETURNING id, status, attempts, received_at`,
+		event.EventID, event.WebhookID, event.Type, event.ChainID, event.Addresses, event.FromBlock, event.ToBlock,
+	).Scan(&event.ID, &event.Status, &event.Attempts, &event.ReceivedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to queue alchemy webhook event: %w", err)
+	}
+	return true, nil
+}
+
+func (r *alchemyWebhookRepository) GetPending(ctx context.Context, limit int) ([]*models.AlchemyWebhookEvent, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, event_id, webhook_id, type, chain_id, addresses, from_block, to_block,
+			   status, attempts, error, received_at, processed_at
+		FROM alchemy_webhook_events
+		WHERE status = $1
+		ORDER BY received_at, id
+		LIMIT $2`, models.AlchemyWebhookEventPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending alchemy webhook events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.AlchemyWebhookEvent
+	for rows.Next() {
+		var e models.AlchemyWebhookEvent
+		if err := rows.Scan(&e.ID, &e.EventID, &e.WebhookID, &e.Type, &e.ChainID, &e.Addresses, &e.FromBlock,
+			&e.ToBlock, &e.Status, &e.Attempts, &e.Error, &e.ReceivedAt, &e.ProcessedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alchemy webhook event: %w", err)
+		}
+		events = append(events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read alchemy webhook events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *alchemyWebhookRepository) MarkProcessed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE alchemy_webhook_events
+		SET status = $2, error = NULL, processed_at = NOW()
+		WHERE id = $1`, id, models.AlchemyWebhookEventProcessed)
+	if err != nil {
+		return fmt.Errorf("failed to mark alchemy webhook event processed: %w", err)
+	}
+	return nil
+}
+
+func (r *alchemyWebhookRepository) RecordError(ctx context.Context, id uuid.UUID, message string, maxAttempts int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE alchemy_webhook_events
+		SET attempts = attempts + 1,
+			error = $2,
+			status = CASE WHEN attempts + 1 >= $3 THEN $4 ELSE status END
+		WHERE id = $1`,
+		id, message, maxAttempts, models.AlchemyWebhookEventFailed)
+	if err != nil {
+		return fmt.Errorf("failed to record alchemy webhook event error: %w", err)
+	}
+	return nil
+}
+
+func (r *alchemyWebhookRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM alchemy_webhook_events
+		WHERE received_at < $1 AND status <> $2`, before, models.AlchemyWebhookEventPending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune alchemy webhook events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *alchemyWebhookRepository) GetWatchedAddresses(ctx context.Context, chainID int) ([]string, error) {
+	return r.addresses(ctx, `
+		SELECT DISTINCT LOWER(address)
+		FROM wallets
+		WHERE chain_namespace = $1 AND chain_id = $2 AND removed_at IS NULL
+		ORDER BY 1`, models.ChainNamespaceEVM, chainID)
+}
+
+func (r *alchemyWebhookRepository) GetSubscribedAddresses(ctx context.Context, webhookID string) ([]string, error) {
+	return r.addresses(ctx, `
+		SELECT address FROM alchemy_webhook_addresses
+		WHERE webhook_id = $1
+		ORDER BY address`, webhookID)
+}
+
+func (r *alchemyWebhookRepository) addresses(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []string{}
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook address: %w", err)
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+func (r *alchemyWebhookRepository) SetSubscribed(ctx context.Context, webhookID string, chainID int, added, removed []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if len(added) > 0 {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO alchemy_webhook_addresses (webhook_id, address, chain_id)
+			SELECT $1, address, $2 FROM UNNEST($3::varchar[]) AS address
+			ON CONFLICT (webhook_id, address) DO NOTHING`, webhookID, chainID, added)
+		if err != nil {
+			return fmt.Errorf("failed to record webhook addresses: %w", err)
+		}
+	}
+	if len(removed) > 0 {
+		_, err := tx.Exec(ctx, `
+			DELETE FROM alchemy_webhook_addresses
+			WHERE webhook_id = $1 AND address = ANY($2)`, webhookID, removed)
+		if err != nil {
+			return fmt.Errorf("failed to remove webhook addresses: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit webhook addresses: %w", err)
+	}
+	return nil
+}
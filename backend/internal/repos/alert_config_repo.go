@@ -0,0 +1,68 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AlertConfigRepository stores the keys naming the alerts a user manages declaratively
+type AlertConfigRepository interface {
+	// GetKeys maps the user's keys to the alerts they name
+	GetKeys(ctx context.Context, userID uuid.UUID) (map[string]uuid.UUID, error)
+	// SetKey names the alert, taking the key from any other alert that had it. Deleting
+	// the alert removes its key.
+	SetKey(ctx context.Context, userID, alertID uuid.UUID, key string) error
+}
+
+type alertConfigRepository struct {
+	db txDB
+}
+
+func NewAlertConfigRepository(db *pgxpool.Pool) AlertConfigRepository {
+	return &alertConfigRepository{db: txDB{pool: db}}
+}
+
+func (r *alertConfigRepository) GetKeys(ctx context.Context, userID uuid.UUID) (map[string]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `SELECT key, alert_id FROM alert_config_keys WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert config keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var key string
+		var alertID uuid.UUID
+		if err := rows.Scan(&key, &alertID); err != nil {
+			return nil, fmt.Errorf("failed to scan alert config key: %w", err)
+		}
+		keys[key] = alertID
+	}
+	return keys, rows.Err()
+}
+
+func (r *alertConfigRepository) SetKey(ctx context.Context, userID, alertID uuid.UUID, key string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM alert_config_keys WHERE user_id = $1 AND key = $2 AND alert_id <> $3`, userID, key, alertID); err != nil {
+		return fmt.Errorf("failed to release alert config key: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO alert_config_keys (alert_id, user_id, key) VALUES ($1, $2, $3)
+		ON CONFLICT (alert_id) DO UPDATE SET key = EXCLUDED.key`,
+		alertID, userID, key); err != nil {
+		return fmt.Errorf("failed to set alert config key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit alert config key: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,104 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AlertCoverageRepository interface {
+	// RecordChecks adds the checks to their alerts' hourly buckets. Failed checks aren't
+	// also counted as stale.
+	RecordChecks(ctx context.Context, checks []models.AlertCheck) error
+	// GetBuckets returns the alert's hourly buckets starting in [from, to), oldest first
+	GetBuckets(ctx context.Context, alertID uuid.UUID, from, to time.Time) ([]models.AlertCoverageBucket, error)
+	// DeleteBefore prunes buckets that started before the time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type alertCoverageRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAlertCoverageRepository(db *pgxpool.Pool) AlertCoverageRepository {
+	return &alertCoverageRepository{db: db}
+}
+
+func (r *alertCoverageRepository) RecordChecks(ctx context.Context, checks []models.AlertCheck) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	alertIDs := make([]uuid.UUID, len(checks))
+	checkedAt := make([]time.Time, len(checks))
+	stale := make([]bool, len(checks))
+	failed := make([]bool, len(checks))
+	dataAt := make([]*time.Time, len(checks))
+	for i, check := range checks {
+		alertIDs[i] = check.AlertID
+		checkedAt[i] = check.CheckedAt
+		stale[i] = check.Stale
+		failed[i] = check.Failed
+		dataAt[i] = check.DataAt
+	}
+
+	// Checks are summed per bucket first, as one statement can't upsert a row twice
+	query := `
+		INSERT INTO alert_coverage (alert_id, bucket, checks, stale_checks, failed_checks, last_checked_at, last_data_at)
+		SELECT c.alert_id, date_trunc('hour', c.checked_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+			   COUNT(*), COUNT(*) FILTER (WHERE c.stale AND NOT c.failed), COUNT(*) FILTER (WHERE c.failed),
+			   MAX(c.checked_at), MAX(c.data_at)
+		FROM UNNEST($1::uuid[], $2::timestamptz[], $3::bool[], $4::bool[], $5::timestamptz[])
+			AS c(alert_id, checked_at, stale, failed, data_at)
+		WHERE EXISTS (SELECT 1 FROM alerts a WHERE a.id = c.alert_id)
+		GROUP BY 1, 2
+		ON CONFLICT (alert_id, bucket) DO UPDATE SET
+			checks = alert_coverage.checks + EXCLUDED.checks,
+			stale_checks = alert_coverage.stale_checks + EXCLUDED.stale_checks,
+			failed_checks = alert_coverage.failed_checks + EXCLUDED.failed_checks,
+			last_checked_at = GREATEST(alert_coverage.last_checked_at, EXCLUDED.last_checked_at),
+			last_data_at = GREATEST(alert_coverage.last_data_at, EXCLUDED.last_data_at)
+	`
+
+	if _, err := r.db.Exec(ctx, query, alertIDs, checkedAt, stale, failed, dataAt); err != nil {
+		return fmt.Errorf("failed to record alert checks: %w", err)
+	}
+	return nil
+}
+
+func (r *alertCoverageRepository) GetBuckets(ctx context.Context, alertID uuid.UUID, from, to time.Time) ([]models.AlertCoverageBucket, error) {
+	query := `
+		SELECT bucket, checks, stale_checks, failed_checks, last_checked_at, last_data_at
+		FROM alert_coverage
+		WHERE alert_id = $1 AND bucket >= $2 AND bucket < $3
+		ORDER BY bucket
+	`
+
+	rows, err := r.db.Query(ctx, query, alertID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert coverage: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []models.AlertCoverageBucket{}
+	for rows.Next() {
+		var b models.AlertCoverageBucket
+		if err := rows.Scan(&b.Bucket, &b.Checks, &b.StaleChecks, &b.FailedChecks, &b.LastCheckedAt, &b.LastDataAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert coverage: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+func (r *alertCoverageRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM alert_coverage WHERE bucket < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune alert coverage: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
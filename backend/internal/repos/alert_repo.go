@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/crypto"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrAlertNotFound is returned when no alert matches. It wraps ErrNotFound.
+var ErrAlertNotFound = fmt.Errorf("alert %w", ErrNotFound)
+
 type AlertRepository interface {
 	Create(ctx context.Context, alert *models.Alert) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Alert, error)
@@ -19,16 +24,24 @@ type AlertRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetActiveAlerts(ctx context.Context) ([]models.Alert, error)
 	UpdateTriggered(ctx context.Context, alertID uuid.UUID) error
+	RecordTrigger(ctx context.Context, history *models.AlertHistory, notification models.AlertNotification, urgent bool) (uuid.UUID, error)
+	SetMutedUntil(ctx context.Context, alertID uuid.UUID, mutedUntil *time.Time) error
 	CreateHistory(ctx context.Context, history *models.AlertHistory) error
 	GetHistory(ctx context.Context, alertID *uuid.UUID, limit, offset int) ([]models.AlertHistory, error)
+	GetStats(ctx context.Context, userID uuid.UUID, since time.Time, bucket string, noisiestLimit int) (*models.AlertStats, error)
+	// GetTriggersSince returns the user's alert triggers since the given time, newest first
+	GetTriggersSince(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]models.TriggeredAlert, error)
 }
 
 type alertRepository struct {
-	db *pgxpool.Pool
+	db        txDB
+	encryptor *crypto.Encryptor
 }
 
-func NewAlertRepository(db *pgxpool.Pool) AlertRepository {
-	return &alertRepository{db: db}
+// NewAlertRepository stores alerts with their webhook URLs sealed by encryptor; a nil
+// encryptor stores them in plaintext
+func NewAlertRepository(db *pgxpool.Pool, encryptor *crypto.Encryptor) AlertRepository {
+	return &alertRepository{db: txDB{pool: db}, encryptor: encryptor}
 }
 
 func (r *alertRepository) Create(ctx context.Context, alert *models.Alert) error {
@@ -42,9 +55,9 @@ func (r *alertRepository) Create(ctx context.Context, alert *models.Alert) error
 		return fmt.Errorf("failed to marshal conditions: %w", err)
 	}
 
-	notificationJSON, err := json.Marshal(alert.Notification)
+	notificationJSON, err := marshalNotification(r.encryptor, alert.Notification)
 	if err != nil {
-		return fmt.Errorf("failed to marshal notification: %w", err)
+		return err
 	}
 
 	query := `
@@ -83,7 +96,8 @@ func (r *alertRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Al
 func (r *alertRepository) GetByUserID(ctx context.Context, userID uuid.UUID, status *string, limit, offset int) ([]models.Alert, error) {
 	query := `
 		SELECT id, user_id, type, status, target, conditions, 
-			   notification, last_triggered_at, trigger_count, created_at, updated_at
+			   notification, last_triggered_at, muted_until, trigger_count, escalation_policy_id, created_at, updated_at,
+			   expired_reason, expired_at
 		FROM alerts
 		WHERE user_id = $1
 		  AND ($2::alert_status IS NULL OR status = $2)
@@ -106,9 +120,9 @@ func (r *alertRepository) Update(ctx context.Context, alert *models.Alert) error
 		return fmt.Errorf("failed to marshal conditions: %w", err)
 	}
 
-	notificationJSON, err := json.Marshal(alert.Notification)
+	notificationJSON, err := marshalNotification(r.encryptor, alert.Notification)
 	if err != nil {
-		return fmt.Errorf("failed to marshal notification: %w", err)
+		return err
 	}
 
 	query := `
@@ -116,6 +130,8 @@ func (r *alertRepository) Update(ctx context.Context, alert *models.Alert) error
 		SET status = $2,
 		    conditions = $3,
 		    notification = $4,
+		    expired_reason = CASE WHEN $2 = 'expired' THEN expired_reason END,
+		    expired_at = CASE WHEN $2 = 'expired' THEN expired_at END,
 		    updated_at = NOW()
 		WHERE id = $1
 	`
@@ -145,7 +161,7 @@ func (r *alertRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("alert not found")
+		return ErrAlertNotFound
 	}
 
 	return nil
@@ -154,7 +170,8 @@ func (r *alertRepository) Delete(ctx context.Context, id uuid.UUID) error {
 func (r *alertRepository) GetActiveAlerts(ctx context.Context) ([]models.Alert, error) {
 	query := `
 		SELECT id, user_id, type, status, target, conditions, 
-			   notification, last_triggered_at, trigger_count, created_at, updated_at
+			   notification, last_triggered_at, muted_until, trigger_count, escalation_policy_id, created_at, updated_at,
+			   expired_reason, expired_at
 		FROM alerts
 		WHERE status = 'active'
 		  AND (last_triggered_at IS NULL 
@@ -187,6 +204,75 @@ func (r *alertRepository) UpdateTriggered(ctx context.Context, alertID uuid.UUID
 	return nil
 }
 
+func (r *alertRepository) SetMutedUntil(ctx context.Context, alertID uuid.UUID, mutedUntil *time.Time) error {
+	result, err := r.db.Exec(ctx, `UPDATE alerts SET muted_until = $2, updated_at = NOW() WHERE id = $1`, alertID, mutedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to update alert mute: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAlertNotFound
+	}
+
+	return nil
+}
+
+// RecordTrigger marks the alert triggered, stores its history row and queues the
+// notification in the outbox in one transaction, returning the outbox row's ID. Either
+// all three happen or none do, so a trigger is never lost or notified twice.
+func (r *alertRepository) RecordTrigger(ctx context.Context, history *models.AlertHistory, notification models.AlertNotification, urgent bool) (uuid.UUID, error) {
+	conditionsJSON, err := json.Marshal(history.ConditionsSnapshot)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to marshal conditions snapshot: %w", err)
+	}
+	triggeredValueJSON, err := json.Marshal(history.TriggeredValue)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to marshal triggered value: %w", err)
+	}
+	notificationJSON, err := marshalNotification(r.encryptor, notification)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE alerts
+		SET last_triggered_at = NOW(),
+		    trigger_count = trigger_count + 1
+		WHERE id = $1`, history.AlertID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update alert trigger: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO alert_history (
+		    id, alert_id, triggered_at, conditions_snapshot,
+		    triggered_value, notification_sent, notification_error
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		history.ID, history.AlertID, history.TriggeredAt, conditionsJSON,
+		triggeredValueJSON, history.NotificationSent, history.NotificationError); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create alert history: %w", err)
+	}
+
+	var outboxID uuid.UUID
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO notification_outbox (alert_id, history_id, history_triggered_at, notification, urgent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		history.AlertID, history.ID, history.TriggeredAt, notificationJSON, urgent).Scan(&outboxID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to queue notification: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit alert trigger: %w", err)
+	}
+	return outboxID, nil
+}
+
 func (r *alertRepository) CreateHistory(ctx context.Context, history *models.AlertHistory) error {
 	conditionsJSON, err := json.Marshal(history.ConditionsSnapshot)
 	if err != nil {
@@ -290,7 +376,8 @@ func (r *alertRepository) GetHistory(ctx context.Context, alertID *uuid.UUID, li
 func (r *alertRepository) populateAlertFromDB(ctx context.Context, id uuid.UUID, alert *models.Alert) error {
 	query := `
 		SELECT id, user_id, type, status, target, conditions, 
-			   notification, last_triggered_at, trigger_count, created_at, updated_at
+			   notification, last_triggered_at, muted_until, trigger_count, escalation_policy_id, created_at, updated_at,
+			   expired_reason, expired_at
 		FROM alerts
 		WHERE id = $1
 	`
@@ -307,14 +394,18 @@ func (r *alertRepository) populateAlertFromDB(ctx context.Context, id uuid.UUID,
 		&conditionsJSON,
 		&notificationJSON,
 		&alert.LastTriggeredAt,
+		&alert.MutedUntil,
 		&alert.TriggerCount,
+		&alert.EscalationPolicyID,
 		&alert.CreatedAt,
 		&alert.UpdatedAt,
+		&alert.ExpiredReason,
+		&alert.ExpiredAt,
 	)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return fmt.Errorf("alert not found")
+			return ErrAlertNotFound
 		}
 		return fmt.Errorf("failed to get alert: %w", err)
 	}
@@ -326,8 +417,8 @@ func (r *alertRepository) populateAlertFromDB(ctx context.Context, id uuid.UUID,
 	if err := json.Unmarshal(conditionsJSON, &alert.Conditions); err != nil {
 		return fmt.Errorf("failed to unmarshal conditions: %w", err)
 	}
-	if err := json.Unmarshal(notificationJSON, &alert.Notification); err != nil {
-		return fmt.Errorf("failed to unmarshal notification: %w", err)
+	if err := unmarshalNotification(r.encryptor, notificationJSON, &alert.Notification); err != nil {
+		return err
 	}
 
 	return nil
@@ -349,9 +440,13 @@ func (r *alertRepository) scanAlerts(rows pgx.Rows) ([]models.Alert, error) {
 			&conditionsJSON,
 			&notificationJSON,
 			&alert.LastTriggeredAt,
+			&alert.MutedUntil,
 			&alert.TriggerCount,
+			&alert.EscalationPolicyID,
 			&alert.CreatedAt,
 			&alert.UpdatedAt,
+			&alert.ExpiredReason,
+			&alert.ExpiredAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan alert: %w", err)
@@ -364,12 +459,151 @@ func (r *alertRepository) scanAlerts(rows pgx.Rows) ([]models.Alert, error) {
 		if err := json.Unmarshal(conditionsJSON, &alert.Conditions); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal conditions: %w", err)
 		}
-		if err := json.Unmarshal(notificationJSON, &alert.Notification); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+		if err := unmarshalNotification(r.encryptor, notificationJSON, &alert.Notification); err != nil {
+			return nil, err
 		}
 
 		alerts = append(alerts, alert)
 	}
 
 	return alerts, rows.Err()
+}
+
+func (r *alertRepository) GetTriggersSince(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]models.TriggeredAlert, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.id, a.type, a.target, h.triggered_at, h.triggered_value
+		FROM alert_history h
+		JOIN alerts a ON a.id = h.alert_id
+		WHERE a.user_id = $1 AND h.triggered_at >= $2
+		ORDER BY h.triggered_at DESC
+		LIMIT $3`,
+		userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var triggers []models.TriggeredAlert
+	for rows.Next() {
+		var trigger models.TriggeredAlert
+		var targetJSON, valueJSON []byte
+		if err := rows.Scan(&trigger.AlertID, &trigger.Type, &targetJSON, &trigger.TriggeredAt, &valueJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan alert trigger: %w", err)
+		}
+		if err := json.Unmarshal(targetJSON, &trigger.Target); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal target: %w", err)
+		}
+		if len(valueJSON) > 0 {
+			if err := json.Unmarshal(valueJSON, &trigger.TriggeredValue); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal triggered value: %w", err)
+			}
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers, rows.Err()
+}
+
+// GetStats aggregates trigger counts from alert_history and delivery outcomes from
+// alert_deliveries for a user's alerts since the given time. bucket is a date_trunc
+// unit such as "hour" or "day".
+func (r *alertRepository) GetStats(ctx context.Context, userID uuid.UUID, since time.Time, bucket string, noisiestLimit int) (*models.AlertStats, error) {
+	stats := &models.AlertStats{
+		Since:          since,
+		Triggers:       []models.AlertTriggerBucket{},
+		Channels:       []models.ChannelDeliveryStats{},
+		NoisiestAlerts: []models.NoisyAlert{},
+	}
+
+	// Trigger counts over time
+	rows, err := r.db.Query(ctx, `
+		SELECT date_trunc($3, h.triggered_at) AS bucket, COUNT(*)
+		FROM alert_history h
+		JOIN alerts a ON a.id = h.alert_id
+		WHERE a.user_id = $1 AND h.triggered_at >= $2
+		GROUP BY bucket
+		ORDER BY bucket
+	`, userID, since, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trigger counts: %w", err)
+	}
+	for rows.Next() {
+		var b models.AlertTriggerBucket
+		if err := rows.Scan(&b.Bucket, &b.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan trigger count: %w", err)
+		}
+		stats.Triggers = append(stats.Triggers, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get trigger counts: %w", err)
+	}
+
+	// Delivery outcomes per channel
+	rows, err = r.db.Query(ctx, `
+		SELECT channel,
+			   COUNT(*) FILTER (WHERE status = 'sent'),
+			   COUNT(*) FILTER (WHERE status = 'failed'),
+			   COUNT(*) FILTER (WHERE status = 'dropped')
+		FROM alert_deliveries
+		WHERE user_id = $1 AND delivered_at >= $2
+		GROUP BY channel
+		ORDER BY channel
+	`, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery stats: %w", err)
+	}
+	for rows.Next() {
+		var c models.ChannelDeliveryStats
+		if err := rows.Scan(&c.Channel, &c.Sent, &c.Failed, &c.Dropped); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan delivery stats: %w", err)
+		}
+		if attempted := c.Sent + c.Failed; attempted > 0 {
+			c.SuccessRate = float64(c.Sent) / float64(attempted)
+		}
+		stats.Channels = append(stats.Channels, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get delivery stats: %w", err)
+	}
+
+	// Median evaluation-to-delivery latency of successful deliveries
+	err = r.db.QueryRow(ctx, `
+		SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY latency_ms)
+		FROM alert_deliveries
+		WHERE user_id = $1 AND delivered_at >= $2 AND status = 'sent'
+	`, userID, since).Scan(&stats.MedianLatencyMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery latency: %w", err)
+	}
+
+	// Alerts that fired most often
+	rows, err = r.db.Query(ctx, `
+		SELECT a.id, a.type, a.target, COUNT(*) AS triggers
+		FROM alert_history h
+		JOIN alerts a ON a.id = h.alert_id
+		WHERE a.user_id = $1 AND h.triggered_at >= $2
+		GROUP BY a.id, a.type, a.target
+		ORDER BY triggers DESC, a.id
+		LIMIT $3
+	`, userID, since, noisiestLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get noisiest alerts: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var n models.NoisyAlert
+		var targetJSON []byte
+		if err := rows.Scan(&n.AlertID, &n.Type, &targetJSON, &n.TriggerCount); err != nil {
+			return nil, fmt.Errorf("failed to scan noisy alert: %w", err)
+		}
+		if err := json.Unmarshal(targetJSON, &n.Target); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal target: %w", err)
+		}
+		stats.NoisiestAlerts = append(stats.NoisiestAlerts, n)
+	}
+
+	return stats, rows.Err()
 }
\ No newline at end of file
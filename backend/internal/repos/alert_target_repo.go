@@ -0,0 +1,227 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// alertTargetSuccessorLimit caps the successors suggested for an expired alert
+const alertTargetSuccessorLimit = 5
+
+// AlertTargetRepository finds alerts whose pool or token target disappeared, expires
+// them and moves them to a successor
+type AlertTargetRepository interface {
+	// GetGone lists live alerts whose target disappeared, oldest first. Pools and tokens
+	// not updated since staleBefore count as delisted; missing ones only count for
+	// alerts created before it, leaving new targets time to be synced.
+	GetGone(ctx context.Context, staleBefore time.Time, limit int) ([]*models.GoneAlertTarget, error)
+	// Expire expires a live alert for the reason and queues the one notification telling
+	// its owner, returning the notification's outbox ID. It returns false, and queues
+	// nothing, when the alert was no longer live.
+	Expire(ctx context.Context, alertID uuid.UUID, reason string, at time.Time) (uuid.UUID, bool, error)
+	// GetSuccessors lists the live pools, or tokens, most like a target that disappeared:
+	// the pool it was migrated to, then pools of the same protocol, chain and symbol by
+	// TVL, or tokens with the same symbol on the chain by market cap
+	GetSuccessors(ctx context.Context, target models.AlertTarget, staleBefore time.Time) ([]*models.AlertTargetSuccessor, error)
+	// IsLive reports whether a pool or token target exists and is still updated
+	IsLive(ctx context.Context, target models.AlertTarget, staleBefore time.Time) (bool, error)
+	// Repoint moves an expired alert to the target and makes it active again
+	Repoint(ctx context.Context, alertID uuid.UUID, target models.AlertTarget) error
+}
+
+type alertTargetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAlertTargetRepository(db *pgxpool.Pool) AlertTargetRepository {
+	return &alertTargetRepository{db: db}
+}
+
+// Pool targets are identified by pool ID and token targets by address and chain. Each
+// takes the staleness cutoff as $1.
+const (
+	goneAlertPool = `a.target->>'type' = 'pool'
+		AND (p.pool_id IS NULL AND a.created_at < $1 OR NOT COALESCE(p.is_active, TRUE) OR p.updated_at < $1)`
+	goneAlertToken = `a.target->>'type' = 'token' AND COALESCE((a.target->>'chainId')::int, 0) > 0
+		AND (t.id IS NULL AND a.created_at < $1
+		     OR COALESCE(m.not_found, FALSE) AND (lp.updated_at IS NULL OR lp.updated_at < $1))`
+)
+
+func (r *alertTargetRepository) GetGone(ctx context.Context, staleBefore time.Time, limit int) ([]*models.GoneAlertTarget, error) {
+	query := `
+		SELECT a.id,
+		       CASE
+		           WHEN a.target->>'type' = 'pool' AND p.pool_id IS NULL THEN $3
+		           WHEN a.target->>'type' = 'pool' AND NOT COALESCE(p.is_active, TRUE) THEN $4
+		           WHEN a.target->>'type' = 'pool' THEN $5
+		           WHEN t.id IS NULL THEN $6
+		           ELSE $7
+		       END
+		FROM alerts a
+		LEFT JOIN yield_pools p ON a.target->>'type' = 'pool' AND p.pool_id = a.target->>'identifier'
+		LEFT JOIN tokens t ON a.target->>'type' = 'token'
+		    AND LOWER(t.address) = LOWER(a.target->>'identifier')
+		    AND t.chain_id = COALESCE((a.target->>'chainId')::int, 0)
+		LEFT JOIN token_metadata m ON m.token_id = t.id
+		LEFT JOIN token_prices_latest lp ON lp.token_id = t.id
+		WHERE a.status IN ('active', 'triggered')
+		  AND (` + goneAlertPool + ` OR ` + goneAlertToken + `)
+		ORDER BY a.created_at, a.id
+		LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, staleBefore, limit,
+		models.AlertExpiredPoolNotFound, models.AlertExpiredPoolDeactivated, models.AlertExpiredPoolDelisted,
+		models.AlertExpiredTokenNotFound, models.AlertExpiredTokenDelisted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts with gone targets: %w", err)
+	}
+	defer rows.Close()
+
+	var gone []*models.GoneAlertTarget
+	for rows.Next() {
+		var g models.GoneAlertTarget
+		if err := rows.Scan(&g.AlertID, &g.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan alert with gone target: %w", err)
+		}
+		gone = append(gone, &g)
+	}
+	return gone, rows.Err()
+}
+
+func (r *alertTargetRepository) Expire(ctx context.Context, alertID uuid.UUID, reason string, at time.Time) (uuid.UUID, bool, error) {
+	value, err := json.Marshal(map[string]interface{}{
+		"event":  models.AlertTargetEventGone,
+		"reason": reason,
+	})
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to marshal expiry: %w", err)
+	}
+
+	// The alert's notification column is copied as stored, encrypted or not. Expiring,
+	// recording and queueing in one statement means the owner hears once.
+	var outboxID uuid.UUID
+	err = r.db.QueryRow(ctx, `
+		WITH expired AS (
+			UPDATE alerts SET status = 'expired', expired_reason = $2, expired_at = $3, updated_at = NOW()
+			WHERE id = $1 AND status IN ('active', 'triggered')
+			RETURNING id, conditions, notification
+		), history AS (
+			INSERT INTO alert_history (id, alert_id, triggered_at, conditions_snapshot, triggered_value, notification_sent)
+			SELECT $4, id, $3, conditions, $5, FALSE FROM expired
+			RETURNING id, alert_id, triggered_at
+		)
+		INSERT INTO notification_outbox (alert_id, history_id, history_triggered_at, notification, urgent)
+		SELECT e.id, h.id, h.triggered_at, e.notification, FALSE
+		FROM expired e JOIN history h ON h.alert_id = e.id
+		RETURNING id`,
+		alertID, reason, at, uuid.New(), value,
+	).Scan(&outboxID)
+	if err == pgx.ErrNoRows {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to expire alert: %w", err)
+	}
+	return outboxID, true, nil
+}
+
+func (r *alertTargetRepository) GetSuccessors(ctx context.Context, target models.AlertTarget, staleBefore time.Time) ([]*models.AlertTargetSuccessor, error) {
+	var (
+		query string
+		args  []interface{}
+	)
+	switch target.Type {
+	case "pool":
+		query = `
+			SELECT n.pool_id, 0, n.pool_name, n.symbol, n.tvl_usd::float8, NULL::float8
+			FROM yield_pools o
+			JOIN yield_pools n ON n.pool_id = o.successor_pool_id
+			    OR n.protocol = o.protocol AND n.chain = o.chain AND n.symbol = o.symbol
+			WHERE o.pool_id = $1 AND n.pool_id <> o.pool_id
+			  AND n.is_active AND n.updated_at >= $2
+			ORDER BY n.pool_id = o.successor_pool_id DESC NULLS LAST, n.tvl_usd DESC NULLS LAST, n.pool_id
+			LIMIT $3`
+		args = []interface{}{target.Identifier, staleBefore, alertTargetSuccessorLimit}
+	case "token":
+		query = `
+			SELECT n.address, n.chain_id, n.name, n.symbol, NULL::float8, n.market_cap::float8
+			FROM tokens o
+			JOIN tokens n ON UPPER(n.symbol) = UPPER(o.symbol) AND n.chain_id = o.chain_id
+			JOIN token_prices_latest lp ON lp.token_id = n.id AND lp.updated_at >= $3
+			WHERE LOWER(o.address) = LOWER($1) AND o.chain_id = $2 AND n.id <> o.id
+			ORDER BY n.market_cap DESC NULLS LAST, n.address
+			LIMIT $4`
+		args = []interface{}{target.Identifier, target.ChainID, staleBefore, alertTargetSuccessorLimit}
+	default:
+		return nil, fmt.Errorf("alert target type %q has no successors", target.Type)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert target successors: %w", err)
+	}
+	defer rows.Close()
+
+	successors := []*models.AlertTargetSuccessor{}
+	for rows.Next() {
+		s := models.AlertTargetSuccessor{Target: models.AlertTarget{Type: target.Type}}
+		if err := rows.Scan(&s.Target.Identifier, &s.Target.ChainID, &s.Name, &s.Symbol, &s.TVLUSD, &s.MarketCap); err != nil {
+			return nil, fmt.Errorf("failed to scan alert target successor: %w", err)
+		}
+		successors = append(successors, &s)
+	}
+	return successors, rows.Err()
+}
+
+func (r *alertTargetRepository) IsLive(ctx context.Context, target models.AlertTarget, staleBefore time.Time) (bool, error) {
+	var (
+		query string
+		args  []interface{}
+	)
+	switch target.Type {
+	case "pool":
+		query = `SELECT EXISTS (SELECT 1 FROM yield_pools
+			WHERE pool_id = $1 AND is_active AND updated_at >= $2)`
+		args = []interface{}{target.Identifier, staleBefore}
+	case "token":
+		query = `SELECT EXISTS (SELECT 1 FROM tokens t
+			LEFT JOIN token_metadata m ON m.token_id = t.id
+			LEFT JOIN token_prices_latest lp ON lp.token_id = t.id
+			WHERE LOWER(t.address) = LOWER($1) AND t.chain_id = $2
+			  AND NOT (COALESCE(m.not_found, FALSE) AND (lp.updated_at IS NULL OR lp.updated_at < $3)))`
+		args = []interface{}{target.Identifier, target.ChainID, staleBefore}
+	default:
+		return false, fmt.Errorf("alert target type %q can't be checked", target.Type)
+	}
+
+	var live bool
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&live); err != nil {
+		return false, fmt.Errorf("failed to check alert target: %w", err)
+	}
+	return live, nil
+}
+
+func (r *alertTargetRepository) Repoint(ctx context.Context, alertID uuid.UUID, target models.AlertTarget) error {
+	targetJSON, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("failed to marshal target: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, `
+		UPDATE alerts SET target = $2, status = 'active', expired_reason = NULL, expired_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'expired'`, alertID, targetJSON)
+	if err != nil {
+		return fmt.Errorf("failed to repoint alert: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlertNotFound
+	}
+	return nil
+}
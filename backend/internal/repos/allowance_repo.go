@@ -0,0 +1,90 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AllowanceRepository stores the ERC-20 approvals wallets have granted
+type AllowanceRepository interface {
+	// GetByWallet lists the wallet's stored allowances, narrowed to a token and a spender
+	// when they're set
+	GetByWallet(ctx context.Context, walletID uuid.UUID, token, spender string) ([]*models.TokenAllowance, error)
+	// SetVerified stores an allowance read on chain at verifiedAt and reports whether a
+	// row holds it. Rows are only created for nonzero allowances of known tokens.
+	SetVerified(ctx context.Context, walletID uuid.UUID, chainID int, token, spender, allowance string, verifiedAt time.Time) (bool, error)
+}
+
+type allowanceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAllowanceRepository(db *pgxpool.Pool) AllowanceRepository {
+	return &allowanceRepository{db: db}
+}
+
+func (r *allowanceRepository) GetByWallet(ctx context.Context, walletID uuid.UUID, token, spender string) ([]*models.TokenAllowance, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.id, a.wallet_id, a.token_id, t.address, t.chain_id, t.symbol, t.name, t.decimals,
+			a.spender_address, a.spender_name, a.allowance::text, a.created_at, a.updated_at, a.verified_at
+		FROM token_allowances a
+		JOIN tokens t ON t.id = a.token_id
+		WHERE a.wallet_id = $1
+			AND ($2 = '' OR t.address = $2)
+			AND ($3 = '' OR a.spender_address = $3)
+		ORDER BY t.address, a.spender_address`,
+		walletID, token, spender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowances: %w", err)
+	}
+	defer rows.Close()
+
+	var allowances []*models.TokenAllowance
+	for rows.Next() {
+		allowance := models.TokenAllowance{Token: &models.Token{}}
+		if err := rows.Scan(&allowance.ID, &allowance.WalletID, &allowance.TokenID, &allowance.Token.Address,
+			&allowance.Token.ChainID, &allowance.Token.Symbol, &allowance.Token.Name, &allowance.Token.Decimals,
+			&allowance.SpenderAddress, &allowance.SpenderName, &allowance.Allowance, &allowance.CreatedAt,
+			&allowance.UpdatedAt, &allowance.VerifiedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan allowance: %w", err)
+		}
+		allowance.Token.ID = allowance.TokenID
+		allowances = append(allowances, &allowance)
+	}
+	return allowances, rows.Err()
+}
+
+func (r *allowanceRepository) SetVerified(ctx context.Context, walletID uuid.UUID, chainID int, token, spender, allowance string, verifiedAt time.Time) (bool, error) {
+	if allowance == "0" {
+		// A revoked approval updates its row but isn't worth a new one
+		tag, err := r.db.Exec(ctx, `
+			UPDATE token_allowances a SET allowance = 0, verified_at = $5
+			FROM tokens t
+			WHERE t.id = a.token_id AND a.wallet_id = $1 AND t.chain_id = $2 AND t.address = $3
+				AND a.spender_address = $4`,
+			walletID, chainID, token, spender, verifiedAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to store allowance: %w", err)
+		}
+		return tag.RowsAffected() > 0, nil
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO token_allowances (wallet_id, token_id, spender_address, allowance, verified_at)
+		SELECT $1, t.id, $4, $5::numeric, $6
+		FROM tokens t
+		WHERE t.address = $3 AND t.chain_id = $2
+		ON CONFLICT (wallet_id, token_id, spender_address) DO UPDATE SET
+			allowance = EXCLUDED.allowance,
+			verified_at = EXCLUDED.verified_at`,
+		walletID, chainID, token, spender, allowance, verifiedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to store allowance: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
\ No newline at end of file
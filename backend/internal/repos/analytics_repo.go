@@ -0,0 +1,71 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AnalyticsRepository keeps which users opted out of usage analytics
+type AnalyticsRepository interface {
+	// GetOptOut returns when the user opted out, nil if they haven't
+	GetOptOut(ctx context.Context, userID uuid.UUID) (*time.Time, error)
+	// SetOptOut opts the user out, keeping the original time if they already were, or
+	// back in
+	SetOptOut(ctx context.Context, userID uuid.UUID, optedOut bool) error
+	// OptedOut returns which of the users opted out
+	OptedOut(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+type analyticsRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAnalyticsRepository(db *pgxpool.Pool) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+func (r *analyticsRepository) GetOptOut(ctx context.Context, userID uuid.UUID) (*time.Time, error) {
+	var optedOutAt time.Time
+	err := r.db.QueryRow(ctx, `SELECT opted_out_at FROM analytics_opt_outs WHERE user_id = $1`, userID).Scan(&optedOutAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics opt-out: %w", err)
+	}
+	return &optedOutAt, nil
+}
+
+func (r *analyticsRepository) SetOptOut(ctx context.Context, userID uuid.UUID, optedOut bool) error {
+	query := `INSERT INTO analytics_opt_outs (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`
+	if !optedOut {
+		query = `DELETE FROM analytics_opt_outs WHERE user_id = $1`
+	}
+	if _, err := r.db.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to set analytics opt-out: %w", err)
+	}
+	return nil
+}
+
+func (r *analyticsRepository) OptedOut(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := r.db.Query(ctx, `SELECT user_id FROM analytics_opt_outs WHERE user_id = ANY($1)`, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics opt-outs: %w", err)
+	}
+	defer rows.Close()
+
+	optedOut := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan analytics opt-out: %w", err)
+		}
+		optedOut[userID] = true
+	}
+	return optedOut, rows.Err()
+}
\ No newline at end of file
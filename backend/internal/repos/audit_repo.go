@@ -0,0 +1,146 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditLogFilter selects audit log entries, newest first
+type AuditLogFilter struct {
+	ActorID      *uuid.UUID
+	TargetUserID *uuid.UUID
+	SessionID    *uuid.UUID
+	// Before pages back past the oldest entry seen
+	Before *time.Time
+	Limit  int
+}
+
+type AuditRepository interface {
+	Record(ctx context.Context, entry *models.AuditLogEntry) error
+	List(ctx context.Context, filter AuditLogFilter) ([]*models.AuditLogEntry, error)
+
+	CreateImpersonationSession(ctx context.Context, session *models.ImpersonationSession) error
+	// GetImpersonationSession returns nil when there's no session with the ID
+	GetImpersonationSession(ctx context.Context, id uuid.UUID) (*models.ImpersonationSession, error)
+	// EndImpersonationSession reports whether the admin had the session open
+	EndImpersonationSession(ctx context.Context, id, adminID uuid.UUID, at time.Time) (bool, error)
+}
+
+type auditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Record(ctx context.Context, entry *models.AuditLogEntry) error {
+	if entry.Metadata == nil {
+		entry.Metadata = map[string]interface{}{}
+	}
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_user_id, session_id, method, path, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(ctx, query,
+		entry.ActorID, entry.Action, entry.TargetUserID, entry.SessionID, entry.Method, entry.Path, entry.Metadata,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit log entry: %w", err)
+	}
+	return nil
+}
+
+func (r *auditRepository) List(ctx context.Context, filter AuditLogFilter) ([]*models.AuditLogEntry, error) {
+	var where whereBuilder
+	if filter.ActorID != nil {
+		where.add("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetUserID != nil {
+		where.add("target_user_id = ?", *filter.TargetUserID)
+	}
+	if filter.SessionID != nil {
+		where.add("session_id = ?", *filter.SessionID)
+	}
+	if filter.Before != nil {
+		where.add("created_at < ?", *filter.Before)
+	}
+	query := `
+		SELECT id, actor_id, action, target_user_id, session_id, method, path, metadata, created_at
+		FROM audit_log
+		` + where.clause() + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + where.arg(filter.Limit)
+
+	rows, err := r.db.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditLogEntry{}
+	for rows.Next() {
+		var e models.AuditLogEntry
+		err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &e.TargetUserID, &e.SessionID, &e.Method, &e.Path, &e.Metadata, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+const impersonationSessionColumns = `id, admin_id, user_id, reason, expires_at, ended_at, created_at`
+
+func scanImpersonationSession(row pgx.Row) (*models.ImpersonationSession, error) {
+	var s models.ImpersonationSession
+	err := row.Scan(&s.ID, &s.AdminID, &s.UserID, &s.Reason, &s.ExpiresAt, &s.EndedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *auditRepository) CreateImpersonationSession(ctx context.Context, session *models.ImpersonationSession) error {
+	query := `
+		INSERT INTO impersonation_sessions (admin_id, user_id, reason, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + impersonationSessionColumns
+
+	created, err := scanImpersonationSession(r.db.QueryRow(ctx, query, session.AdminID, session.UserID, session.Reason, session.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to create impersonation session: %w", err)
+	}
+	*session = *created
+	return nil
+}
+
+func (r *auditRepository) GetImpersonationSession(ctx context.Context, id uuid.UUID) (*models.ImpersonationSession, error) {
+	query := `SELECT ` + impersonationSessionColumns + ` FROM impersonation_sessions WHERE id = $1`
+
+	session, err := scanImpersonationSession(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation session: %w", err)
+	}
+	return session, nil
+}
+
+func (r *auditRepository) EndImpersonationSession(ctx context.Context, id, adminID uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE impersonation_sessions SET ended_at = $3
+		WHERE id = $1 AND admin_id = $2 AND ended_at IS NULL`, id, adminID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to end impersonation session: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,197 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BalanceRefreshRepository interface {
+	// Create queues a refresh, counting the wallets it covers into its total
+	Create(ctx context.Context, refresh *models.BalanceRefresh) error
+	// GetByID returns nil when there's no refresh with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BalanceRefresh, error)
+	// List returns the latest refreshes, newest first
+	List(ctx context.Context, limit int) ([]*models.BalanceRefresh, error)
+	// GetNextActive returns the oldest pending or running refresh, or nil when there's none
+	GetNextActive(ctx context.Context) (*models.BalanceRefresh, error)
+	// Start marks a pending refresh running. It updates refresh in place.
+	Start(ctx context.Context, refresh *models.BalanceRefresh, at time.Time) error
+	// NextWallets returns the refresh's next wallets after its cursor, by ID
+	NextWallets(ctx context.Context, refresh *models.BalanceRefresh, limit int) ([]*models.Wallet, error)
+	// SaveProgress stores the refresh's counts, cursor and last error, completing it when
+	// done. It updates refresh in place.
+	SaveProgress(ctx context.Context, refresh *models.BalanceRefresh, done bool, at time.Time) error
+}
+
+type balanceRefreshRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBalanceRefreshRepository(db *pgxpool.Pool) BalanceRefreshRepository {
+	return &balanceRefreshRepository{db: db}
+}
+
+const balanceRefreshColumns = `id, requested_by, wallet_ids, chain_id, status, total, refreshed, failed,
+	cursor, last_error, started_at, completed_at, created_at, updated_at`
+
+// balanceRefreshWallets matches the EVM wallets a refresh covers, given its wallet IDs as
+// $1 and chain as $2; both are optional
+const balanceRefreshWallets = `w.chain_namespace = 'evm' AND w.removed_at IS NULL
+	AND ($1::uuid[] IS NULL OR w.id = ANY($1))
+	AND ($2::int IS NULL OR w.chain_id = $2)`
+
+func scanBalanceRefresh(row pgx.Row) (*models.BalanceRefresh, error) {
+	var r models.BalanceRefresh
+	err := row.Scan(
+		&r.ID,
+		&r.RequestedBy,
+		&r.WalletIDs,
+		&r.ChainID,
+		&r.Status,
+		&r.Total,
+		&r.Refreshed,
+		&r.Failed,
+		&r.Cursor,
+		&r.LastError,
+		&r.StartedAt,
+		&r.CompletedAt,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// walletIDsArg passes no wallet IDs as NULL, selecting every wallet
+func walletIDsArg(ids []uuid.UUID) interface{} {
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+func (r *balanceRefreshRepository) Create(ctx context.Context, refresh *models.BalanceRefresh) error {
+	query := `
+		INSERT INTO balance_refreshes (requested_by, wallet_ids, chain_id, total)
+		SELECT $3, $1, $2, COUNT(*) FROM wallets w WHERE ` + balanceRefreshWallets + `
+		RETURNING ` + balanceRefreshColumns
+
+	created, err := scanBalanceRefresh(r.db.QueryRow(ctx, query, walletIDsArg(refresh.WalletIDs), refresh.ChainID, refresh.RequestedBy))
+	if err != nil {
+		return fmt.Errorf("failed to create balance refresh: %w", err)
+	}
+	*refresh = *created
+	return nil
+}
+
+func (r *balanceRefreshRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BalanceRefresh, error) {
+	query := `SELECT ` + balanceRefreshColumns + ` FROM balance_refreshes WHERE id = $1`
+
+	refresh, err := scanBalanceRefresh(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance refresh: %w", err)
+	}
+	return refresh, nil
+}
+
+func (r *balanceRefreshRepository) List(ctx context.Context, limit int) ([]*models.BalanceRefresh, error) {
+	query := `SELECT ` + balanceRefreshColumns + ` FROM balance_refreshes ORDER BY created_at DESC, id DESC LIMIT $1`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance refreshes: %w", err)
+	}
+	defer rows.Close()
+
+	refreshes := []*models.BalanceRefresh{}
+	for rows.Next() {
+		refresh, err := scanBalanceRefresh(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance refresh: %w", err)
+		}
+		refreshes = append(refreshes, refresh)
+	}
+	return refreshes, rows.Err()
+}
+
+func (r *balanceRefreshRepository) GetNextActive(ctx context.Context) (*models.BalanceRefresh, error) {
+	query := `SELECT ` + balanceRefreshColumns + ` FROM balance_refreshes
+		WHERE status IN ($1, $2)
+		ORDER BY created_at, id
+		LIMIT 1`
+
+	refresh, err := scanBalanceRefresh(r.db.QueryRow(ctx, query, models.BalanceRefreshPending, models.BalanceRefreshRunning))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active balance refresh: %w", err)
+	}
+	return refresh, nil
+}
+
+func (r *balanceRefreshRepository) Start(ctx context.Context, refresh *models.BalanceRefresh, at time.Time) error {
+	query := `UPDATE balance_refreshes SET status = $2, started_at = $3
+		WHERE id = $1
+		RETURNING ` + balanceRefreshColumns
+
+	started, err := scanBalanceRefresh(r.db.QueryRow(ctx, query, refresh.ID, models.BalanceRefreshRunning, at))
+	if err != nil {
+		return fmt.Errorf("failed to start balance refresh: %w", err)
+	}
+	*refresh = *started
+	return nil
+}
+
+func (r *balanceRefreshRepository) NextWallets(ctx context.Context, refresh *models.BalanceRefresh, limit int) ([]*models.Wallet, error) {
+	query := `SELECT ` + walletColumns + ` FROM wallets w
+		WHERE ` + balanceRefreshWallets + ` AND ($3::uuid IS NULL OR w.id > $3)
+		ORDER BY w.id
+		LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, walletIDsArg(refresh.WalletIDs), refresh.ChainID, refresh.Cursor, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance refresh wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, rows.Err()
+}
+
+func (r *balanceRefreshRepository) SaveProgress(ctx context.Context, refresh *models.BalanceRefresh, done bool, at time.Time) error {
+	query := `UPDATE balance_refreshes SET
+			refreshed = $2, failed = $3, cursor = $4, last_error = $5,
+			status = CASE WHEN $6 THEN $7 ELSE status END,
+			completed_at = CASE WHEN $6 THEN $8 ELSE completed_at END
+		WHERE id = $1
+		RETURNING ` + balanceRefreshColumns
+
+	saved, err := scanBalanceRefresh(r.db.QueryRow(ctx, query,
+		refresh.ID, refresh.Refreshed, refresh.Failed, refresh.Cursor, refresh.LastError,
+		done, models.BalanceRefreshCompleted, at,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to save balance refresh progress: %w", err)
+	}
+	*refresh = *saved
+	return nil
+}
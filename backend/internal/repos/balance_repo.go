@@ -0,0 +1,256 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BalanceRepository interface {
+	// ReplaceWalletBalances stores a wallet's balances as just fetched from the chain,
+	// creating rows for tokens not seen before and removing balances of tokens the wallet
+	// no longer holds. Balances need their Token set. It records and returns the changes
+	// from the balances stored before.
+	ReplaceWalletBalances(ctx context.Context, walletID uuid.UUID, chainID int, balances []*models.Balance) ([]*models.BalanceChange, error)
+	// GetChangesSince returns the balance changes of the wallets at an address detected
+	// after since, oldest first; chainID 0 covers every chain
+	GetChangesSince(ctx context.Context, address string, chainID int, since time.Time) ([]*models.BalanceChange, error)
+}
+
+type balanceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBalanceRepository(db *pgxpool.Pool) BalanceRepository {
+	return &balanceRepository{db: db}
+}
+
+// storedBalance is a wallet's balance of a token before a refresh
+type storedBalance struct {
+	token      *models.Token
+	balance    string
+	balanceUSD *float64
+}
+
+func (r *balanceRepository) ReplaceWalletBalances(ctx context.Context, walletID uuid.UUID, chainID int, balances []*models.Balance) ([]*models.BalanceChange, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the stored balances makes concurrent refreshes of a wallet take turns, so
+	// each one's changes are against the other's result
+	rows, err := tx.Query(ctx, `
+		SELECT b.token_id, b.balance::text, b.balance_usd, t.address, t.chain_id, t.symbol, t.name, t.decimals
+		FROM balances b
+		JOIN tokens t ON t.id = b.token_id
+		WHERE b.wallet_id = $1
+		FOR UPDATE OF b`, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored balances: %w", err)
+	}
+	previous := make(map[uuid.UUID]storedBalance)
+	for rows.Next() {
+		var stored storedBalance
+		var token models.Token
+		if err := rows.Scan(&token.ID, &stored.balance, &stored.balanceUSD, &token.Address, &token.ChainID, &token.Symbol, &token.Name, &token.Decimals); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan stored balance: %w", err)
+		}
+		stored.token = &token
+		previous[token.ID] = stored
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get stored balances: %w", err)
+	}
+
+	var changes []*models.BalanceChange
+	held := make([]uuid.UUID, 0, len(balances))
+	fetched := make(map[uuid.UUID]*models.Balance, len(balances))
+	for _, balance := range balances {
+		if balance.Token == nil {
+			continue
+		}
+		token := balance.Token
+
+		// Tokens are keyed by their lower-cased address on the chain, as UpsertTokens stores them
+		var tokenID uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO tokens (address, chain_id, symbol, name, decimals, logo_uri)
+			VALUES ($1, $2, LEFT($3, 50), LEFT($4, 255), $5, $6)
+			ON CONFLICT (address, chain_id) DO UPDATE SET
+				logo_uri = COALESCE(tokens.logo_uri, EXCLUDED.logo_uri)
+			RETURNING id`,
+			strings.ToLower(token.Address), chainID, token.Symbol, token.Name, token.Decimals, token.LogoURI,
+		).Scan(&tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert token: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO balances (wallet_id, token_id, balance, balance_usd, block_number, block_hash)
+			VALUES ($1, $2, $3::numeric, $4, $5, $6)
+			ON CONFLICT (wallet_id, token_id) DO UPDATE SET
+				balance = EXCLUDED.balance,
+				balance_usd = EXCLUDED.balance_usd,
+				block_number = COALESCE(EXCLUDED.block_number, balances.block_number),
+				block_hash = CASE WHEN EXCLUDED.block_number IS NULL THEN balances.block_hash ELSE EXCLUDED.block_hash END`,
+			walletID, tokenID, balance.Balance, balance.BalanceUSD, balance.BlockNumber, balance.BlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert balance: %w", err)
+		}
+		held = append(held, tokenID)
+		fetched[tokenID] = balance
+
+		stored, ok := previous[tokenID]
+		delete(previous, tokenID)
+		if !ok {
+			stored.balance = "0"
+		}
+		if change := newBalanceChange(stored.balance, balance.Balance, stored.balanceUSD, balance.BalanceUSD); change != nil {
+			change.TokenID, change.Token, change.BlockNumber = tokenID, token, balance.BlockNumber
+			changes = append(changes, change)
+		}
+	}
+
+	// What's left was held before but no longer is
+	for tokenID, stored := range previous {
+		if change := newBalanceChange(stored.balance, "0", stored.balanceUSD, nil); change != nil {
+			change.TokenID, change.Token = tokenID, stored.token
+			changes = append(changes, change)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM balances WHERE wallet_id = $1 AND NOT (token_id = ANY($2))`, walletID, held); err != nil {
+		return nil, fmt.Errorf("failed to delete stale balances: %w", err)
+	}
+
+	for _, change := range changes {
+		change.WalletID = walletID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO balance_changes (wallet_id, token_id, previous_balance, new_balance, delta, delta_usd, block_number)
+			VALUES ($1, $2, $3::numeric, $4::numeric, $5::numeric, $6, $7)
+			RETURNING id, detected_at`,
+			walletID, change.TokenID, change.PreviousBalance, change.NewBalance, change.Delta, change.DeltaUSD, change.BlockNumber,
+		).Scan(&change.ID, &change.DetectedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record balance change: %w", err)
+		}
+
+		// History keeps each new balance with the block it was read at, so a reorg of
+		// that block removes it; balances no longer held take the refresh's block
+		entry := fetched[change.TokenID]
+		if entry == nil {
+			entry = &models.Balance{Balance: "0"}
+			if len(balances) > 0 {
+				entry.BlockNumber, entry.BlockHash = balances[0].BlockNumber, balances[0].BlockHash
+			}
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO balance_history (wallet_id, token_id, balance, balance_usd, block_number, block_hash)
+			VALUES ($1, $2, $3::numeric, $4, $5, $6)`,
+			walletID, change.TokenID, entry.Balance, entry.BalanceUSD, entry.BlockNumber, entry.BlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record balance history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit balances: %w", err)
+	}
+	return changes, nil
+}
+
+// newBalanceChange compares a stored balance with the one just fetched, returning nil
+// when they're equal or either can't be read. The change is valued at the current
+// price, taken from the new balance's value, or from the previous one's when the new
+// balance is zero or unpriced.
+func newBalanceChange(previous, current string, previousUSD, currentUSD *float64) *models.BalanceChange {
+	prev, ok := new(big.Int).SetString(previous, 10)
+	if !ok {
+		return nil
+	}
+	cur, ok := new(big.Int).SetString(current, 10)
+	if !ok {
+		return nil
+	}
+	delta := new(big.Int).Sub(cur, prev)
+	if delta.Sign() == 0 {
+		return nil
+	}
+
+	change := &models.BalanceChange{
+		PreviousBalance: prev.String(),
+		NewBalance:      cur.String(),
+		Delta:           delta.String(),
+	}
+	priced, value := cur, currentUSD
+	if cur.Sign() == 0 || currentUSD == nil {
+		priced, value = prev, previousUSD
+	}
+	if value != nil && priced.Sign() != 0 {
+		deltaUSD, _ := new(big.Float).Quo(
+			new(big.Float).Mul(new(big.Float).SetInt(delta), big.NewFloat(*value)),
+			new(big.Float).SetInt(priced),
+		).Float64()
+		change.DeltaUSD = &deltaUSD
+	}
+	return change
+}
+
+func (r *balanceRepository) GetChangesSince(ctx context.Context, address string, chainID int, since time.Time) ([]*models.BalanceChange, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT bc.id, bc.wallet_id, bc.token_id, bc.previous_balance::text, bc.new_balance::text, bc.delta::text,
+			bc.delta_usd, bc.block_number, bc.detected_at,
+			t.address, t.chain_id, t.symbol, t.name, t.decimals
+		FROM balance_changes bc
+		JOIN wallets w ON w.id = bc.wallet_id
+		JOIN tokens t ON t.id = bc.token_id
+		WHERE w.address = $1
+			AND ($2 = 0 OR w.chain_id = $2)
+			AND bc.detected_at > $3
+		ORDER BY bc.detected_at, bc.id`,
+		addr.Normalize(address), chainID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*models.BalanceChange
+	for rows.Next() {
+		var change models.BalanceChange
+		var token models.Token
+		err := rows.Scan(
+			&change.ID,
+			&change.WalletID,
+			&change.TokenID,
+			&change.PreviousBalance,
+			&change.NewBalance,
+			&change.Delta,
+			&change.DeltaUSD,
+			&change.BlockNumber,
+			&change.DetectedAt,
+			&token.Address,
+			&token.ChainID,
+			&token.Symbol,
+			&token.Name,
+			&token.Decimals,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan balance change: %w", err)
+		}
+		token.ID = change.TokenID
+		change.Token = &token
+		changes = append(changes, &change)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,35 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBalanceChange(t *testing.T) {
+	usd := func(v float64) *float64 { return &v }
+
+	assert.Nil(t, newBalanceChange("100", "100", usd(1), usd(1)), "unchanged")
+	assert.Nil(t, newBalanceChange("100", "not a number", nil, nil))
+
+	// Received 50 of a token now worth 3 a unit
+	change := newBalanceChange("100", "150", usd(200), usd(450))
+	require.NotNil(t, change)
+	assert.Equal(t, "50", change.Delta)
+	require.NotNil(t, change.DeltaUSD)
+	assert.InDelta(t, 150, *change.DeltaUSD, 1e-9)
+
+	// A token no longer held is valued at its last known price
+	change = newBalanceChange("400", "0", usd(800), nil)
+	require.NotNil(t, change)
+	assert.Equal(t, "-400", change.Delta)
+	assert.Equal(t, "0", change.NewBalance)
+	assert.InDelta(t, -800, *change.DeltaUSD, 1e-9)
+
+	// A new token without a price has no value
+	change = newBalanceChange("0", "12500000000000000000", nil, nil)
+	require.NotNil(t, change)
+	assert.Equal(t, "12500000000000000000", change.Delta)
+	assert.Nil(t, change.DeltaUSD)
+}
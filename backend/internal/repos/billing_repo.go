@@ -0,0 +1,189 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BillingRepository interface {
+	// GetAccount returns the user's billing account, nil if they never checked out
+	GetAccount(ctx context.Context, userID uuid.UUID) (*models.BillingAccount, error)
+	// GetAccountByCustomer returns the account of a Stripe customer, nil if it's unknown
+	GetAccountByCustomer(ctx context.Context, customerID string) (*models.BillingAccount, error)
+	// SaveAccount inserts or updates the account, setting its UpdatedAt
+	SaveAccount(ctx context.Context, account *models.BillingAccount) error
+	// GetExpiredGrace returns the accounts whose grace period ended before now
+	GetExpiredGrace(ctx context.Context, now time.Time) ([]*models.BillingAccount, error)
+	// SaveInvoice inserts or updates an invoice
+	SaveInvoice(ctx context.Context, invoice *models.BillingInvoice) error
+	// ListInvoices returns the user's invoices, newest first
+	ListInvoices(ctx context.Context, userID uuid.UUID, limit int) ([]*models.BillingInvoice, error)
+	// EventHandled reports whether a Stripe event was recorded as handled
+	EventHandled(ctx context.Context, eventID string) (bool, error)
+	// RecordEvent records a Stripe event as handled
+	RecordEvent(ctx context.Context, eventID, eventType string) error
+}
+
+type billingRepository struct {
+	db txDB
+}
+
+func NewBillingRepository(db *pgxpool.Pool) BillingRepository {
+	return &billingRepository{db: txDB{pool: db}}
+}
+
+const billingAccountColumns = `user_id, stripe_customer_id, stripe_subscription_id, status,
+	current_period_end, cancel_at_period_end, grace_until, last_event_at, updated_at`
+
+func scanBillingAccount(row pgx.Row) (*models.BillingAccount, error) {
+	var account models.BillingAccount
+	err := row.Scan(&account.UserID, &account.StripeCustomerID, &account.StripeSubscriptionID, &account.Status,
+		&account.CurrentPeriodEnd, &account.CancelAtPeriodEnd, &account.GraceUntil, &account.LastEventAt, &account.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *billingRepository) GetAccount(ctx context.Context, userID uuid.UUID) (*models.BillingAccount, error) {
+	account, err := scanBillingAccount(r.db.QueryRow(ctx,
+		`SELECT `+billingAccountColumns+` FROM billing_accounts WHERE user_id = $1`, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billing account: %w", err)
+	}
+	return account, nil
+}
+
+func (r *billingRepository) GetAccountByCustomer(ctx context.Context, customerID string) (*models.BillingAccount, error) {
+	account, err := scanBillingAccount(r.db.QueryRow(ctx,
+		`SELECT `+billingAccountColumns+` FROM billing_accounts WHERE stripe_customer_id = $1`, customerID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billing account by customer: %w", err)
+	}
+	return account, nil
+}
+
+func (r *billingRepository) SaveAccount(ctx context.Context, account *models.BillingAccount) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO billing_accounts (user_id, stripe_customer_id, stripe_subscription_id, status,
+			current_period_end, cancel_at_period_end, grace_until, last_event_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			stripe_customer_id = EXCLUDED.stripe_customer_id,
+			stripe_subscription_id = EXCLUDED.stripe_subscription_id,
+			status = EXCLUDED.status,
+			current_period_end = EXCLUDED.current_period_end,
+			cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+			grace_until = EXCLUDED.grace_until,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`,
+		account.UserID, account.StripeCustomerID, account.StripeSubscriptionID, account.Status,
+		account.CurrentPeriodEnd, account.CancelAtPeriodEnd, account.GraceUntil, account.LastEventAt,
+	).Scan(&account.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save billing account: %w", err)
+	}
+	return nil
+}
+
+func (r *billingRepository) GetExpiredGrace(ctx context.Context, now time.Time) ([]*models.BillingAccount, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+billingAccountColumns+` FROM billing_accounts WHERE grace_until < $1`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired grace periods: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*models.BillingAccount
+	for rows.Next() {
+		account, err := scanBillingAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan billing account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+func (r *billingRepository) SaveInvoice(ctx context.Context, invoice *models.BillingInvoice) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO billing_invoices (id, user_id, number, status, amount_due, amount_paid, currency,
+			hosted_invoice_url, invoice_pdf, period_start, period_end, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			number = EXCLUDED.number,
+			status = EXCLUDED.status,
+			amount_due = EXCLUDED.amount_due,
+			amount_paid = EXCLUDED.amount_paid,
+			hosted_invoice_url = EXCLUDED.hosted_invoice_url,
+			invoice_pdf = EXCLUDED.invoice_pdf,
+			updated_at = NOW()`,
+		invoice.ID, invoice.UserID, invoice.Number, invoice.Status, invoice.AmountDue, invoice.AmountPaid,
+		invoice.Currency, invoice.HostedInvoiceURL, invoice.InvoicePDF, invoice.PeriodStart, invoice.PeriodEnd,
+		invoice.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save invoice: %w", err)
+	}
+	return nil
+}
+
+func (r *billingRepository) ListInvoices(ctx context.Context, userID uuid.UUID, limit int) ([]*models.BillingInvoice, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, number, status, amount_due, amount_paid, currency, hosted_invoice_url,
+			invoice_pdf, period_start, period_end, created_at
+		FROM billing_invoices
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+	defer rows.Close()
+
+	invoices := []*models.BillingInvoice{}
+	for rows.Next() {
+		var invoice models.BillingInvoice
+		err := rows.Scan(&invoice.ID, &invoice.UserID, &invoice.Number, &invoice.Status, &invoice.AmountDue,
+			&invoice.AmountPaid, &invoice.Currency, &invoice.HostedInvoiceURL, &invoice.InvoicePDF,
+			&invoice.PeriodStart, &invoice.PeriodEnd, &invoice.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, &invoice)
+	}
+	return invoices, rows.Err()
+}
+
+func (r *billingRepository) EventHandled(ctx context.Context, eventID string) (bool, error) {
+	var handled bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM billing_events WHERE id = $1)`, eventID).Scan(&handled)
+	if err != nil {
+		return false, fmt.Errorf("failed to check billing event: %w", err)
+	}
+	return handled, nil
+}
+
+func (r *billingRepository) RecordEvent(ctx context.Context, eventID, eventType string) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO billing_events (id, type) VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING`,
+		eventID, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to record billing event: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
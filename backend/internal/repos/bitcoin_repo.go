@@ -0,0 +1,222 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BitcoinRepository interface {
+	GetAccounts(ctx context.Context, userID uuid.UUID) ([]*models.BitcoinAccount, error)
+	GetAllAccounts(ctx context.Context) ([]*models.BitcoinAccount, error)
+	AddAccount(ctx context.Context, account *models.BitcoinAccount) error
+	DeleteAccount(ctx context.Context, userID, accountID uuid.UUID) (bool, error)
+	MarkSynced(ctx context.Context, accountID uuid.UUID, syncedAt time.Time) error
+	GetKnownTxids(ctx context.Context, accountID uuid.UUID) (map[string]bool, error)
+	InsertTransactions(ctx context.Context, accountID uuid.UUID, txs []*models.BitcoinTransaction) error
+	GetTransactions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.BitcoinTransaction, error)
+	GetTransactionsBetween(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.BitcoinTransaction, error)
+}
+
+type bitcoinRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBitcoinRepository(db *pgxpool.Pool) BitcoinRepository {
+	return &bitcoinRepository{db: db}
+}
+
+const bitcoinAccountColumns = `id, user_id, kind, identifier, label, last_synced_at, created_at`
+
+const bitcoinTransactionColumns = `t.id, t.account_id, t.txid, t.block_height, t.block_time,
+	t.amount_sats, t.fee_sats, t.price_usd::float8`
+
+func (r *bitcoinRepository) GetAccounts(ctx context.Context, userID uuid.UUID) ([]*models.BitcoinAccount, error) {
+	query := `SELECT ` + bitcoinAccountColumns + ` FROM bitcoin_accounts WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bitcoin accounts: %w", err)
+	}
+	return scanBitcoinAccounts(rows)
+}
+
+// GetAllAccounts returns every tracked account, least recently synced first
+func (r *bitcoinRepository) GetAllAccounts(ctx context.Context) ([]*models.BitcoinAccount, error) {
+	query := `SELECT ` + bitcoinAccountColumns + ` FROM bitcoin_accounts ORDER BY last_synced_at NULLS FIRST`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bitcoin accounts: %w", err)
+	}
+	return scanBitcoinAccounts(rows)
+}
+
+// AddAccount tracks an address or xpub for the user; adding one already tracked updates its label
+func (r *bitcoinRepository) AddAccount(ctx context.Context, account *models.BitcoinAccount) error {
+	query := `
+		INSERT INTO bitcoin_accounts (user_id, kind, identifier, label)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, identifier) DO UPDATE SET
+			label = EXCLUDED.label
+		RETURNING id, last_synced_at, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, account.UserID, account.Kind, account.Identifier, account.Label).
+		Scan(&account.ID, &account.LastSyncedAt, &account.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add bitcoin account: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount stops tracking an account and reports whether the user tracked it
+func (r *bitcoinRepository) DeleteAccount(ctx context.Context, userID, accountID uuid.UUID) (bool, error) {
+	query := `DELETE FROM bitcoin_accounts WHERE user_id = $1 AND id = $2`
+
+	result, err := r.db.Exec(ctx, query, userID, accountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete bitcoin account: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *bitcoinRepository) MarkSynced(ctx context.Context, accountID uuid.UUID, syncedAt time.Time) error {
+	query := `UPDATE bitcoin_accounts SET last_synced_at = $2 WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, accountID, syncedAt); err != nil {
+		return fmt.Errorf("failed to mark bitcoin account synced: %w", err)
+	}
+
+	return nil
+}
+
+func (r *bitcoinRepository) GetKnownTxids(ctx context.Context, accountID uuid.UUID) (map[string]bool, error) {
+	rows, err := r.db.Query(ctx, `SELECT txid FROM bitcoin_transactions WHERE account_id = $1`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bitcoin txids: %w", err)
+	}
+	defer rows.Close()
+
+	known := make(map[string]bool)
+	for rows.Next() {
+		var txid string
+		if err := rows.Scan(&txid); err != nil {
+			return nil, fmt.Errorf("failed to scan bitcoin txid: %w", err)
+		}
+		known[txid] = true
+	}
+
+	return known, rows.Err()
+}
+
+// InsertTransactions stores an account's new transactions; ones already stored are left as is
+func (r *bitcoinRepository) InsertTransactions(ctx context.Context, accountID uuid.UUID, txs []*models.BitcoinTransaction) error {
+	query := `
+		INSERT INTO bitcoin_transactions (
+			id, account_id, txid, block_height, block_time, amount_sats, fee_sats, price_usd
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (account_id, txid) DO NOTHING
+	`
+
+	for _, tx := range txs {
+		tx.AccountID = accountID
+		_, err := r.db.Exec(ctx, query,
+			tx.ID,
+			tx.AccountID,
+			tx.Txid,
+			tx.BlockHeight,
+			tx.Timestamp,
+			tx.AmountSats,
+			tx.FeeSats,
+			tx.PriceUSD,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert bitcoin transaction %s: %w", tx.Txid, err)
+		}
+	}
+
+	return nil
+}
+
+// GetTransactions returns a page of the user's transactions across accounts, newest first
+func (r *bitcoinRepository) GetTransactions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.BitcoinTransaction, error) {
+	query := `
+		SELECT ` + bitcoinTransactionColumns + `
+		FROM bitcoin_transactions t
+		JOIN bitcoin_accounts a ON a.id = t.account_id
+		WHERE a.user_id = $1
+		ORDER BY t.block_time DESC, t.txid
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bitcoin transactions: %w", err)
+	}
+	return scanBitcoinTransactions(rows)
+}
+
+// GetTransactionsBetween returns the user's transactions in a time range, oldest first
+func (r *bitcoinRepository) GetTransactionsBetween(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]*models.BitcoinTransaction, error) {
+	query := `
+		SELECT ` + bitcoinTransactionColumns + `
+		FROM bitcoin_transactions t
+		JOIN bitcoin_accounts a ON a.id = t.account_id
+		WHERE a.user_id = $1 AND t.block_time BETWEEN $2 AND $3
+		ORDER BY t.block_time, t.txid
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bitcoin transactions: %w", err)
+	}
+	return scanBitcoinTransactions(rows)
+}
+
+func scanBitcoinAccounts(rows pgx.Rows) ([]*models.BitcoinAccount, error) {
+	defer rows.Close()
+
+	var accounts []*models.BitcoinAccount
+	for rows.Next() {
+		var a models.BitcoinAccount
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Kind, &a.Identifier, &a.Label, &a.LastSyncedAt, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bitcoin account: %w", err)
+		}
+		accounts = append(accounts, &a)
+	}
+
+	return accounts, rows.Err()
+}
+
+func scanBitcoinTransactions(rows pgx.Rows) ([]*models.BitcoinTransaction, error) {
+	defer rows.Close()
+
+	var txs []*models.BitcoinTransaction
+	for rows.Next() {
+		var tx models.BitcoinTransaction
+		err := rows.Scan(
+			&tx.ID,
+			&tx.AccountID,
+			&tx.Txid,
+			&tx.BlockHeight,
+			&tx.Timestamp,
+			&tx.AmountSats,
+			&tx.FeeSats,
+			&tx.PriceUSD,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bitcoin transaction: %w", err)
+		}
+		txs = append(txs, &tx)
+	}
+
+	return txs, rows.Err()
+}
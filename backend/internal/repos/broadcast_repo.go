@@ -0,0 +1,232 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BroadcastSegment is a broadcast's audience resolved to what's in the database. Users
+// holding a matching token or with an active position in the protocol are in it.
+type BroadcastSegment struct {
+	// ChainID and TokenAddress match a token on a chain; the address is lower case
+	ChainID      *int
+	TokenAddress string
+	ProtocolID   *uuid.UUID
+	// TokenSymbols match tokens by upper-case symbol on any chain
+	TokenSymbols []string
+}
+
+// broadcastAudienceCTE selects the IDs of the users in a segment, given as $1 to $4
+const broadcastAudienceCTE = `
+	WITH audience AS (
+		SELECT w.user_id
+		FROM wallets w
+		JOIN balances b ON b.wallet_id = w.id AND b.balance > 0
+		JOIN tokens t ON t.id = b.token_id
+		WHERE w.removed_at IS NULL
+		  AND (($1::int IS NOT NULL AND t.chain_id = $1 AND LOWER(t.address) = $2)
+		       OR UPPER(t.symbol) = ANY($4::text[]))
+		UNION
+		SELECT yp.user_id
+		FROM yield_positions yp
+		WHERE yp.is_active AND yp.protocol_id = $3::uuid
+	)
+`
+
+func (s BroadcastSegment) args() []interface{} {
+	symbols := s.TokenSymbols
+	if symbols == nil {
+		symbols = []string{}
+	}
+	return []interface{}{s.ChainID, s.TokenAddress, s.ProtocolID, symbols}
+}
+
+// BroadcastRepository stores the one-off messages admins send to a segment of users and
+// who they reached
+type BroadcastRepository interface {
+	// PreviewAudience counts the users in the segment and returns the addresses of up to
+	// sampleSize of them
+	PreviewAudience(ctx context.Context, segment BroadcastSegment, sampleSize int) (*models.BroadcastPreview, error)
+	// Create stores the broadcast with the users in the segment as its recipients, and its
+	// banner when it has one, filling in its ID, recipients and creation time. Recipients
+	// with an email address are left pending an email when the broadcast sends one.
+	Create(ctx context.Context, broadcast *models.Broadcast, segment BroadcastSegment) error
+	// List returns the latest broadcasts, newest first
+	List(ctx context.Context, limit int) ([]*models.Broadcast, error)
+	// GetPendingEmails lists broadcast emails not sent yet, oldest broadcast first
+	GetPendingEmails(ctx context.Context, limit int) ([]*models.BroadcastEmail, error)
+	// MarkEmailed records that the recipient's email was sent
+	MarkEmailed(ctx context.Context, broadcastID, userID uuid.UUID, at time.Time) error
+}
+
+type broadcastRepository struct {
+	db txDB
+}
+
+func NewBroadcastRepository(db *pgxpool.Pool) BroadcastRepository {
+	return &broadcastRepository{db: txDB{pool: db}}
+}
+
+func (r *broadcastRepository) PreviewAudience(ctx context.Context, segment BroadcastSegment, sampleSize int) (*models.BroadcastPreview, error) {
+	preview := &models.BroadcastPreview{Sample: []string{}}
+	err := r.db.QueryRow(ctx, broadcastAudienceCTE+`
+		SELECT COUNT(*), COUNT(u.email) FILTER (WHERE u.email <> '')
+		FROM audience a
+		JOIN users u ON u.id = a.user_id
+	`, segment.args()...).Scan(&preview.Users, &preview.Emailable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count broadcast audience: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, broadcastAudienceCTE+`
+		SELECT u.address
+		FROM audience a
+		JOIN users u ON u.id = a.user_id
+		ORDER BY u.address
+		LIMIT $5
+	`, append(segment.args(), sampleSize)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample broadcast audience: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, fmt.Errorf("failed to scan broadcast recipient: %w", err)
+		}
+		preview.Sample = append(preview.Sample, address)
+	}
+	return preview, rows.Err()
+}
+
+func (r *broadcastRepository) Create(ctx context.Context, broadcast *models.Broadcast, segment BroadcastSegment) error {
+	audience, err := json.Marshal(broadcast.Audience)
+	if err != nil {
+		return fmt.Errorf("failed to marshal broadcast audience: %w", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO broadcasts (title, message, level, audience, banner, email, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, broadcast.Title, broadcast.Message, broadcast.Level, audience, broadcast.Banner, broadcast.Email, broadcast.CreatedBy).
+		Scan(&broadcast.ID, &broadcast.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create broadcast: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, broadcastAudienceCTE+`
+		INSERT INTO broadcast_recipients (broadcast_id, user_id, email_pending)
+		SELECT $5, u.id, $6 AND COALESCE(u.email, '') <> ''
+		FROM audience a
+		JOIN users u ON u.id = a.user_id
+	`, append(segment.args(), broadcast.ID, broadcast.Email)...)
+	if err != nil {
+		return fmt.Errorf("failed to add broadcast recipients: %w", err)
+	}
+	broadcast.Recipients = int(tag.RowsAffected())
+
+	err = tx.QueryRow(ctx, `
+		UPDATE broadcasts SET recipients = $2 WHERE id = $1
+		RETURNING (SELECT COUNT(*) FROM broadcast_recipients WHERE broadcast_id = $1 AND email_pending)
+	`, broadcast.ID, broadcast.Recipients).Scan(&broadcast.EmailsPending)
+	if err != nil {
+		return fmt.Errorf("failed to update broadcast recipients: %w", err)
+	}
+
+	if broadcast.Banner {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO system_banners (title, message, level, active, broadcast_id)
+			VALUES ($1, $2, $3, TRUE, $4)
+		`, broadcast.Title, broadcast.Message, broadcast.Level, broadcast.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create broadcast banner: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *broadcastRepository) List(ctx context.Context, limit int) ([]*models.Broadcast, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT b.id, b.title, b.message, b.level, b.audience, b.banner, b.email, b.recipients,
+		       (SELECT COUNT(*) FROM broadcast_recipients r WHERE r.broadcast_id = b.id AND r.email_pending),
+		       b.created_by, b.created_at
+		FROM broadcasts b
+		ORDER BY b.created_at DESC, b.id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broadcasts: %w", err)
+	}
+	defer rows.Close()
+
+	broadcasts := []*models.Broadcast{}
+	for rows.Next() {
+		var b models.Broadcast
+		var audience []byte
+		err := rows.Scan(&b.ID, &b.Title, &b.Message, &b.Level, &audience, &b.Banner, &b.Email,
+			&b.Recipients, &b.EmailsPending, &b.CreatedBy, &b.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan broadcast: %w", err)
+		}
+		if err := json.Unmarshal(audience, &b.Audience); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal broadcast audience: %w", err)
+		}
+		broadcasts = append(broadcasts, &b)
+	}
+	return broadcasts, rows.Err()
+}
+
+func (r *broadcastRepository) GetPendingEmails(ctx context.Context, limit int) ([]*models.BroadcastEmail, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT r.broadcast_id, r.user_id, u.email, b.title, b.message
+		FROM broadcast_recipients r
+		JOIN broadcasts b ON b.id = r.broadcast_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.email_pending AND COALESCE(u.email, '') <> ''
+		ORDER BY b.created_at, r.user_id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending broadcast emails: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []*models.BroadcastEmail{}
+	for rows.Next() {
+		var e models.BroadcastEmail
+		if err := rows.Scan(&e.BroadcastID, &e.UserID, &e.Email, &e.Title, &e.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan broadcast email: %w", err)
+		}
+		emails = append(emails, &e)
+	}
+	return emails, rows.Err()
+}
+
+func (r *broadcastRepository) MarkEmailed(ctx context.Context, broadcastID, userID uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE broadcast_recipients SET email_pending = FALSE, emailed_at = $3
+		WHERE broadcast_id = $1 AND user_id = $2
+	`, broadcastID, userID, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark broadcast emailed: %w", err)
+	}
+	return nil
+}
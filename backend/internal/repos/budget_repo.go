@@ -0,0 +1,128 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BudgetRepository interface {
+	// GetByUser returns the user's budgets by metric
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.Budget, error)
+	// Get returns nil when the user has no budget for the metric
+	Get(ctx context.Context, userID uuid.UUID, metric string) (*models.Budget, error)
+	// Upsert creates or replaces the limit of the user's budget for its metric. It
+	// updates budget in place.
+	Upsert(ctx context.Context, budget *models.Budget) error
+	// Delete reports whether the user had a budget for the metric
+	Delete(ctx context.Context, userID uuid.UUID, metric string) (bool, error)
+	// GetTradingVolume returns the USD value of the tokens the user's wallets sold in
+	// swaps in [from, to), from their PnL lots
+	GetTradingVolume(ctx context.Context, userID uuid.UUID, from, to time.Time) (float64, error)
+}
+
+type budgetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBudgetRepository(db *pgxpool.Pool) BudgetRepository {
+	return &budgetRepository{db: db}
+}
+
+const budgetColumns = `id, user_id, metric, limit_usd::float8, created_at, updated_at`
+
+func scanBudget(row pgx.Row) (*models.Budget, error) {
+	var b models.Budget
+	err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.Metric,
+		&b.LimitUSD,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *budgetRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE user_id = $1 ORDER BY metric`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := []*models.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, rows.Err()
+}
+
+func (r *budgetRepository) Get(ctx context.Context, userID uuid.UUID, metric string) (*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE user_id = $1 AND metric = $2`
+
+	budget, err := scanBudget(r.db.QueryRow(ctx, query, userID, metric))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	return budget, nil
+}
+
+func (r *budgetRepository) Upsert(ctx context.Context, budget *models.Budget) error {
+	query := `
+		INSERT INTO budgets (user_id, metric, limit_usd)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, metric) DO UPDATE SET limit_usd = EXCLUDED.limit_usd
+		RETURNING ` + budgetColumns
+
+	saved, err := scanBudget(r.db.QueryRow(ctx, query, budget.UserID, budget.Metric, budget.LimitUSD))
+	if err != nil {
+		return fmt.Errorf("failed to save budget: %w", err)
+	}
+	*budget = *saved
+	return nil
+}
+
+func (r *budgetRepository) Delete(ctx context.Context, userID uuid.UUID, metric string) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM budgets WHERE user_id = $1 AND metric = $2`, userID, metric)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *budgetRepository) GetTradingVolume(ctx context.Context, userID uuid.UUID, from, to time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(l.quantity * l.price_usd), 0)::float8
+		FROM pnl_lots l
+		JOIN wallets w ON w.id = l.wallet_id
+		JOIN transactions t ON t.hash = l.transaction_hash AND t.chain_id = l.chain_id
+		WHERE w.user_id = $1
+		  AND l.type = 'sell'
+		  AND t.type = 'swap'
+		  AND l.timestamp >= $2 AND l.timestamp < $3
+	`
+
+	var volume float64
+	if err := r.db.QueryRow(ctx, query, userID, from, to).Scan(&volume); err != nil {
+		return 0, fmt.Errorf("failed to get trading volume: %w", err)
+	}
+	return volume, nil
+}
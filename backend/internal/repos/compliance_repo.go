@@ -0,0 +1,258 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrScreeningListExists is returned when a screening list with the name already exists
+var ErrScreeningListExists = errors.New("screening list already exists")
+
+// ComplianceRepository keeps the address lists counterparties are screened against and
+// the exposure the screening found per wallet
+type ComplianceRepository interface {
+	// GetLists returns the screening lists by name, with how many addresses each holds
+	GetLists(ctx context.Context) ([]*models.ScreeningList, error)
+	// CreateList adds a list with its addresses, which are stored lowercase
+	CreateList(ctx context.Context, list *models.ScreeningList, addresses []string) error
+	// SetAddresses replaces a list's addresses, returning false when there's no such list
+	SetAddresses(ctx context.Context, listID uuid.UUID, addresses []string) (bool, error)
+	// DeleteList removes a list, returning false when there's no such list
+	DeleteList(ctx context.Context, listID uuid.UUID) (bool, error)
+	// GetHits returns the transactions of tracked wallets with listed addresses, per
+	// wallet, address and direction. Failed and dropped transactions, and suspected
+	// address poisoning, don't count.
+	GetHits(ctx context.Context) ([]*models.ExposureHit, error)
+	// SaveExposures replaces the stored exposures with those of a screening at at
+	SaveExposures(ctx context.Context, exposures []*models.WalletExposure, at time.Time) error
+	// GetByUser returns the exposures of the user's wallets, most severe first
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WalletExposure, error)
+	// GetFlagged returns the exposures at one of levels across users, most severe first
+	GetFlagged(ctx context.Context, levels []string, limit int) ([]*models.WalletExposure, error)
+}
+
+type complianceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewComplianceRepository(db *pgxpool.Pool) ComplianceRepository {
+	return &complianceRepository{db: db}
+}
+
+func (r *complianceRepository) GetLists(ctx context.Context) ([]*models.ScreeningList, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT l.id, l.name, l.category, COUNT(a.address), l.created_at, l.updated_at
+		FROM screening_lists l
+		LEFT JOIN screening_list_addresses a ON a.list_id = l.id
+		GROUP BY l.id
+		ORDER BY l.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screening lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []*models.ScreeningList{}
+	for rows.Next() {
+		var l models.ScreeningList
+		if err := rows.Scan(&l.ID, &l.Name, &l.Category, &l.AddressCount, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan screening list: %w", err)
+		}
+		lists = append(lists, &l)
+	}
+	return lists, rows.Err()
+}
+
+func (r *complianceRepository) CreateList(ctx context.Context, list *models.ScreeningList, addresses []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO screening_lists (name, category) VALUES ($1, $2)
+		RETURNING id, created_at, updated_at`, list.Name, list.Category,
+	).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+	if isUniqueViolation(err, "screening_lists_name_key") {
+		return ErrScreeningListExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create screening list: %w", err)
+	}
+	if err := insertScreeningAddresses(ctx, tx, list.ID, addresses); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit screening list: %w", err)
+	}
+	list.AddressCount = len(addresses)
+	return nil
+}
+
+func (r *complianceRepository) SetAddresses(ctx context.Context, listID uuid.UUID, addresses []string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE screening_lists SET updated_at = NOW() WHERE id = $1`, listID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update screening list: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM screening_list_addresses WHERE list_id = $1`, listID); err != nil {
+		return false, fmt.Errorf("failed to clear screening list addresses: %w", err)
+	}
+	if err := insertScreeningAddresses(ctx, tx, listID, addresses); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit screening list addresses: %w", err)
+	}
+	return true, nil
+}
+
+// insertScreeningAddresses adds addresses to a list as part of tx
+func insertScreeningAddresses(ctx context.Context, tx pgx.Tx, listID uuid.UUID, addresses []string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO screening_list_addresses (list_id, address)
+		SELECT $1, lower(a) FROM unnest($2::text[]) AS a
+		ON CONFLICT DO NOTHING`, listID, addresses)
+	if err != nil {
+		return fmt.Errorf("failed to add screening list addresses: %w", err)
+	}
+	return nil
+}
+
+func (r *complianceRepository) DeleteList(ctx context.Context, listID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM screening_lists WHERE id = $1`, listID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete screening list: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *complianceRepository) GetHits(ctx context.Context) ([]*models.ExposureHit, error) {
+	// Each direction joins on its own indexed address column
+	query := `
+		WITH screened AS (
+			SELECT t.id, t.chain_id, t.timestamp, t.from_address AS wallet_address, $1::text AS direction, a.address, a.list_id
+			FROM screening_list_addresses a
+			JOIN transactions t ON lower(t.to_address) = a.address
+			WHERE t.status::text NOT IN ('failed', 'dropped') AND t.poisoning_lookalike_of IS NULL
+			UNION ALL
+			SELECT t.id, t.chain_id, t.timestamp, t.to_address, $2::text, a.address, a.list_id
+			FROM screening_list_addresses a
+			JOIN transactions t ON lower(t.from_address) = a.address
+			WHERE t.status::text NOT IN ('failed', 'dropped') AND t.poisoning_lookalike_of IS NULL
+		)
+		SELECT ut.wallet_id, s.address, l.name, l.category, s.direction, COUNT(DISTINCT s.id), MAX(s.timestamp)
+		FROM screened s
+		JOIN user_transactions ut ON ut.transaction_id = s.id AND ut.chain_id = s.chain_id
+		JOIN wallets w ON w.id = ut.wallet_id AND w.removed_at IS NULL AND lower(w.address) = lower(s.wallet_address)
+		JOIN screening_lists l ON l.id = s.list_id
+		GROUP BY ut.wallet_id, s.address, l.name, l.category, s.direction
+		ORDER BY ut.wallet_id, MAX(s.timestamp) DESC, s.address`
+
+	rows, err := r.db.Query(ctx, query, models.ExposureSent, models.ExposureReceived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screening hits: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []*models.ExposureHit
+	for rows.Next() {
+		var h models.ExposureHit
+		if err := rows.Scan(&h.WalletID, &h.Address, &h.List, &h.Category, &h.Direction, &h.TxCount, &h.LastAt); err != nil {
+			return nil, fmt.Errorf("failed to scan screening hit: %w", err)
+		}
+		hits = append(hits, &h)
+	}
+	return hits, rows.Err()
+}
+
+func (r *complianceRepository) SaveExposures(ctx context.Context, exposures []*models.WalletExposure, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, e := range exposures {
+		hitsJSON, err := json.Marshal(e.Hits)
+		if err != nil {
+			return fmt.Errorf("failed to marshal exposure hits: %w", err)
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO wallet_exposures (wallet_id, level, hits, screened_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (wallet_id) DO UPDATE SET
+				level = EXCLUDED.level, hits = EXCLUDED.hits, screened_at = EXCLUDED.screened_at`,
+			e.WalletID, e.Level, hitsJSON, at)
+		if err != nil {
+			return fmt.Errorf("failed to save wallet exposure: %w", err)
+		}
+	}
+	// Wallets no longer exposed, e.g. after a list was changed, are cleared
+	if _, err := tx.Exec(ctx, `DELETE FROM wallet_exposures WHERE screened_at < $1`, at); err != nil {
+		return fmt.Errorf("failed to clear wallet exposures: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit wallet exposures: %w", err)
+	}
+	return nil
+}
+
+// walletExposureQuery selects exposures with their wallets, most severe first
+const walletExposureQuery = `
+	SELECT e.wallet_id, w.user_id, w.address, w.chain_id, e.level, e.hits, e.screened_at
+	FROM wallet_exposures e
+	JOIN wallets w ON w.id = e.wallet_id AND w.removed_at IS NULL
+	WHERE %s
+	ORDER BY CASE e.level WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END DESC,
+	         e.wallet_id`
+
+func (r *complianceRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WalletExposure, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(walletExposureQuery, "w.user_id = $1"), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet exposures: %w", err)
+	}
+	return scanWalletExposures(rows)
+}
+
+func (r *complianceRepository) GetFlagged(ctx context.Context, levels []string, limit int) ([]*models.WalletExposure, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(walletExposureQuery, "e.level = ANY($1)")+" LIMIT $2", levels, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flagged wallet exposures: %w", err)
+	}
+	return scanWalletExposures(rows)
+}
+
+func scanWalletExposures(rows pgx.Rows) ([]*models.WalletExposure, error) {
+	defer rows.Close()
+
+	exposures := []*models.WalletExposure{}
+	for rows.Next() {
+		var e models.WalletExposure
+		var hitsJSON []byte
+		if err := rows.Scan(&e.WalletID, &e.UserID, &e.Address, &e.ChainID, &e.Level, &hitsJSON, &e.ScreenedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet exposure: %w", err)
+		}
+		if err := json.Unmarshal(hitsJSON, &e.Hits); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal exposure hits: %w", err)
+		}
+		exposures = append(exposures, &e)
+	}
+	return exposures, rows.Err()
+}
@@ -0,0 +1,144 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ContractRepository caches what chain explorers know of the contracts tokens and
+// spenders live at
+type ContractRepository interface {
+	// GetStale lists the addresses of held ERC-20 tokens and of spenders wallets gave
+	// allowances to that were never looked up or were looked up longer ago than
+	// olderThan, never-looked-up spenders first
+	GetStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.ContractRef, error)
+	Upsert(ctx context.Context, info *models.ContractInfo) error
+	// GetMany returns the contracts looked up among addresses on a chain, keyed by
+	// lowercase address
+	GetMany(ctx context.Context, chainID int, addresses []string) (map[string]*models.ContractInfo, error)
+}
+
+type contractRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewContractRepository(db *pgxpool.Pool) ContractRepository {
+	return &contractRepository{db: db}
+}
+
+func (r *contractRepository) GetStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.ContractRef, error) {
+	query := `
+		WITH referenced AS (
+			SELECT DISTINCT t.chain_id, LOWER(a.spender_address) AS address, TRUE AS spender
+			FROM token_allowances a
+			JOIN tokens t ON t.id = a.token_id
+			JOIN wallets w ON w.id = a.wallet_id AND w.removed_at IS NULL
+			WHERE a.allowance > 0
+			UNION
+			SELECT DISTINCT t.chain_id, LOWER(t.address), FALSE
+			FROM balances b
+			JOIN tokens t ON t.id = b.token_id
+			JOIN wallets w ON w.id = b.wallet_id AND w.removed_at IS NULL
+			WHERE b.balance > 0 AND t.chain_id > 0
+			  AND t.address ~* '^0x[0-9a-f]{40}$'
+			  AND t.address <> '0x0000000000000000000000000000000000000000'
+		)
+		SELECT r.chain_id, r.address
+		FROM referenced r
+		LEFT JOIN contracts c ON c.chain_id = r.chain_id AND c.address = r.address
+		WHERE c.address IS NULL OR c.fetched_at < $1
+		GROUP BY r.chain_id, r.address, c.fetched_at
+		ORDER BY c.fetched_at ASC NULLS FIRST, bool_or(r.spender) DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, time.Now().Add(-olderThan), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []models.ContractRef
+	for rows.Next() {
+		var ref models.ContractRef
+		if err := rows.Scan(&ref.ChainID, &ref.Address); err != nil {
+			return nil, fmt.Errorf("failed to scan contract: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+func (r *contractRepository) Upsert(ctx context.Context, info *models.ContractInfo) error {
+	query := `
+		INSERT INTO contracts (
+			chain_id, address, is_contract, verified, contract_name, is_proxy,
+			implementation_address, implementation_verified, creator_address,
+			creation_tx_hash, deployed_at, source, fetched_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+		ON CONFLICT (chain_id, address) DO UPDATE SET
+			is_contract = EXCLUDED.is_contract,
+			verified = EXCLUDED.verified,
+			contract_name = EXCLUDED.contract_name,
+			is_proxy = EXCLUDED.is_proxy,
+			implementation_address = EXCLUDED.implementation_address,
+			implementation_verified = EXCLUDED.implementation_verified,
+			creator_address = EXCLUDED.creator_address,
+			creation_tx_hash = EXCLUDED.creation_tx_hash,
+			deployed_at = EXCLUDED.deployed_at,
+			source = EXCLUDED.source,
+			fetched_at = EXCLUDED.fetched_at
+		RETURNING fetched_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		info.ChainID, strings.ToLower(info.Address), info.IsContract, info.Verified, info.Name, info.IsProxy,
+		info.Implementation, info.ImplementationVerified, info.Creator,
+		info.CreationTxHash, info.DeployedAt, info.Source,
+	).Scan(&info.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert contract: %w", err)
+	}
+	return nil
+}
+
+func (r *contractRepository) GetMany(ctx context.Context, chainID int, addresses []string) (map[string]*models.ContractInfo, error) {
+	contracts := make(map[string]*models.ContractInfo)
+	if len(addresses) == 0 {
+		return contracts, nil
+	}
+	lower := make([]string, len(addresses))
+	for i, address := range addresses {
+		lower[i] = strings.ToLower(address)
+	}
+
+	query := `
+		SELECT chain_id, address, is_contract, verified, contract_name, is_proxy,
+		       implementation_address, implementation_verified, creator_address,
+		       creation_tx_hash, deployed_at, source, fetched_at
+		FROM contracts
+		WHERE chain_id = $1 AND address = ANY($2)
+	`
+
+	rows, err := r.db.Query(ctx, query, chainID, lower)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contracts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var info models.ContractInfo
+		if err := rows.Scan(&info.ChainID, &info.Address, &info.IsContract, &info.Verified, &info.Name, &info.IsProxy,
+			&info.Implementation, &info.ImplementationVerified, &info.Creator,
+			&info.CreationTxHash, &info.DeployedAt, &info.Source, &info.FetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan contract: %w", err)
+		}
+		contracts[info.Address] = &info
+	}
+	return contracts, rows.Err()
+}
@@ -0,0 +1,120 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CustomChainRepository interface {
+	GetAll(ctx context.Context) ([]*models.CustomChain, error)
+	// GetByChainID returns nil when no custom chain has the ID
+	GetByChainID(ctx context.Context, chainID int) (*models.CustomChain, error)
+	Create(ctx context.Context, chain *models.CustomChain) error
+	Delete(ctx context.Context, chainID int) error
+}
+
+type customChainRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomChainRepository(db *pgxpool.Pool) CustomChainRepository {
+	return &customChainRepository{db: db}
+}
+
+const customChainColumns = `chain_id, name, rpc_url, explorer_url, native_symbol, native_coingecko_id,
+	is_testnet, created_by, created_at, updated_at`
+
+func scanCustomChain(row pgx.Row) (*models.CustomChain, error) {
+	var chain models.CustomChain
+	err := row.Scan(
+		&chain.ChainID,
+		&chain.Name,
+		&chain.RPCURL,
+		&chain.ExplorerURL,
+		&chain.NativeSymbol,
+		&chain.NativeCoinGeckoID,
+		&chain.IsTestnet,
+		&chain.CreatedBy,
+		&chain.CreatedAt,
+		&chain.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &chain, nil
+}
+
+func (r *customChainRepository) GetAll(ctx context.Context) ([]*models.CustomChain, error) {
+	query := `SELECT ` + customChainColumns + ` FROM custom_chains ORDER BY chain_id`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom chains: %w", err)
+	}
+	defer rows.Close()
+
+	var chains []*models.CustomChain
+	for rows.Next() {
+		chain, err := scanCustomChain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom chain: %w", err)
+		}
+		chains = append(chains, chain)
+	}
+
+	return chains, rows.Err()
+}
+
+func (r *customChainRepository) GetByChainID(ctx context.Context, chainID int) (*models.CustomChain, error) {
+	query := `SELECT ` + customChainColumns + ` FROM custom_chains WHERE chain_id = $1`
+
+	chain, err := scanCustomChain(r.db.QueryRow(ctx, query, chainID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom chain: %w", err)
+	}
+	return chain, nil
+}
+
+func (r *customChainRepository) Create(ctx context.Context, chain *models.CustomChain) error {
+	query := `
+		INSERT INTO custom_chains (chain_id, name, rpc_url, explorer_url, native_symbol,
+			native_coingecko_id, is_testnet, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		chain.ChainID,
+		chain.Name,
+		chain.RPCURL,
+		chain.ExplorerURL,
+		chain.NativeSymbol,
+		chain.NativeCoinGeckoID,
+		chain.IsTestnet,
+		chain.CreatedBy,
+	).Scan(&chain.CreatedAt, &chain.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create custom chain: %w", err)
+	}
+
+	return nil
+}
+
+func (r *customChainRepository) Delete(ctx context.Context, chainID int) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM custom_chains WHERE chain_id = $1`, chainID)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom chain: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("custom chain not found")
+	}
+
+	return nil
+}
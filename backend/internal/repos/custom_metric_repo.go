@@ -0,0 +1,203 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrCustomMetricNameTaken is returned when the user already has a metric with the name
+var ErrCustomMetricNameTaken = errors.New("custom metric name already taken")
+
+type CustomMetricRepository interface {
+	Create(ctx context.Context, metric *models.CustomMetric) error
+	// GetByID returns nil when there's no metric with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.CustomMetric, error)
+	// GetByUser lists the user's metrics by position, then name
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.CustomMetric, error)
+	// Update saves the name, expression, format and position, and drops the cached value
+	Update(ctx context.Context, metric *models.CustomMetric) error
+	// Delete reports whether the user had a metric with the ID
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	// SaveValue caches the metric's value, or the error evaluating it, computed at the time
+	SaveValue(ctx context.Context, id uuid.UUID, value *float64, evalErr *string, at time.Time) error
+	// GetHoldings returns the user's holdings metrics are computed from: the token balances
+	// in their wallets and their active yield positions
+	GetHoldings(ctx context.Context, userID uuid.UUID) ([]*models.MetricHolding, error)
+}
+
+type customMetricRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomMetricRepository(db *pgxpool.Pool) CustomMetricRepository {
+	return &customMetricRepository{db: db}
+}
+
+const customMetricColumns = `id, user_id, name, expression, format, position, value, error, computed_at, created_at, updated_at`
+
+func scanCustomMetric(row pgx.Row) (*models.CustomMetric, error) {
+	var m models.CustomMetric
+	err := row.Scan(
+		&m.ID,
+		&m.UserID,
+		&m.Name,
+		&m.Expression,
+		&m.Format,
+		&m.Position,
+		&m.Value,
+		&m.Error,
+		&m.ComputedAt,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *customMetricRepository) Create(ctx context.Context, metric *models.CustomMetric) error {
+	query := `
+		INSERT INTO custom_metrics (user_id, name, expression, format, position)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + customMetricColumns
+
+	saved, err := scanCustomMetric(r.db.QueryRow(ctx, query,
+		metric.UserID,
+		metric.Name,
+		metric.Expression,
+		metric.Format,
+		metric.Position,
+	))
+	if isUniqueViolation(err, "custom_metrics_user_id_name_key") {
+		return ErrCustomMetricNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create custom metric: %w", err)
+	}
+	*metric = *saved
+	return nil
+}
+
+func (r *customMetricRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.CustomMetric, error) {
+	query := `SELECT ` + customMetricColumns + ` FROM custom_metrics WHERE id = $1`
+
+	metric, err := scanCustomMetric(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom metric: %w", err)
+	}
+	return metric, nil
+}
+
+func (r *customMetricRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.CustomMetric, error) {
+	query := `
+		SELECT ` + customMetricColumns + `
+		FROM custom_metrics
+		WHERE user_id = $1
+		ORDER BY position, name, id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom metrics: %w", err)
+	}
+	defer rows.Close()
+
+	metrics := []*models.CustomMetric{}
+	for rows.Next() {
+		metric, err := scanCustomMetric(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom metric: %w", err)
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, rows.Err()
+}
+
+func (r *customMetricRepository) Update(ctx context.Context, metric *models.CustomMetric) error {
+	query := `
+		UPDATE custom_metrics
+		SET name = $3, expression = $4, format = $5, position = $6,
+		    value = NULL, error = NULL, computed_at = NULL
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + customMetricColumns
+
+	saved, err := scanCustomMetric(r.db.QueryRow(ctx, query,
+		metric.ID,
+		metric.UserID,
+		metric.Name,
+		metric.Expression,
+		metric.Format,
+		metric.Position,
+	))
+	if isUniqueViolation(err, "custom_metrics_user_id_name_key") {
+		return ErrCustomMetricNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update custom metric: %w", err)
+	}
+	*metric = *saved
+	return nil
+}
+
+func (r *customMetricRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM custom_metrics WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete custom metric: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *customMetricRepository) SaveValue(ctx context.Context, id uuid.UUID, value *float64, evalErr *string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE custom_metrics SET value = $2, error = $3, computed_at = $4 WHERE id = $1
+	`, id, value, evalErr, at)
+	if err != nil {
+		return fmt.Errorf("failed to save custom metric value: %w", err)
+	}
+	return nil
+}
+
+func (r *customMetricRepository) GetHoldings(ctx context.Context, userID uuid.UUID) ([]*models.MetricHolding, error) {
+	query := `
+		SELECT UPPER(t.symbol), COALESCE(b.balance_usd, 0)::float8,
+		       COALESCE('Stablecoins' = ANY(m.categories), FALSE), FALSE, NULL::float8
+		FROM wallets w
+		JOIN balances b ON b.wallet_id = w.id AND b.balance > 0
+		JOIN tokens t ON t.id = b.token_id
+		LEFT JOIN token_metadata m ON m.token_id = t.id
+		WHERE w.user_id = $1 AND w.removed_at IS NULL
+		UNION ALL
+		SELECT UPPER(yp.symbol), COALESCE(p.balance_usd, 0)::float8,
+		       COALESCE(yp.stable_coin, FALSE), TRUE, yp.apy::float8
+		FROM yield_positions p
+		JOIN yield_pools yp ON yp.id = p.pool_id
+		WHERE p.user_id = $1 AND p.is_active
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric holdings: %w", err)
+	}
+	defer rows.Close()
+
+	holdings := []*models.MetricHolding{}
+	for rows.Next() {
+		var h models.MetricHolding
+		if err := rows.Scan(&h.Symbol, &h.ValueUSD, &h.Stablecoin, &h.Position, &h.APY); err != nil {
+			return nil, fmt.Errorf("failed to scan metric holding: %w", err)
+		}
+		holdings = append(holdings, &h)
+	}
+	return holdings, rows.Err()
+}
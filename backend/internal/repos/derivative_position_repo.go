@@ -0,0 +1,221 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DerivativePositionRepository interface {
+	GetTrackedAddresses(ctx context.Context) ([]string, error)
+	SyncPositions(ctx context.Context, address, venue string, positions []*models.DerivativePosition) error
+	GetOpenByAddresses(ctx context.Context, addresses []string) ([]*models.DerivativePosition, error)
+	InsertFundingPayments(ctx context.Context, payments []*models.FundingPayment) (int64, error)
+	GetLatestFundingTime(ctx context.Context, address, venue string) (*time.Time, error)
+}
+
+type derivativePositionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDerivativePositionRepository(db *pgxpool.Pool) DerivativePositionRepository {
+	return &derivativePositionRepository{db: db}
+}
+
+// GetTrackedAddresses returns every distinct wallet address users have added, lowercased
+func (r *derivativePositionRepository) GetTrackedAddresses(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT DISTINCT LOWER(address) FROM wallets WHERE removed_at IS NULL ORDER BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracked addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var addresses []string
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, fmt.Errorf("failed to scan tracked address: %w", err)
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+// SyncPositions makes the given positions the wallet's open positions on the venue:
+// they are upserted, and previously open positions missing from the list are closed
+func (r *derivativePositionRepository) SyncPositions(ctx context.Context, address, venue string, positions []*models.DerivativePosition) error {
+	address = strings.ToLower(address)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	upsert := `
+		INSERT INTO derivative_positions (
+			wallet_address, venue, chain_id, market, symbol, side, size, size_usd,
+			entry_price, mark_price, liquidation_price, leverage, collateral_usd,
+			unrealized_pnl_usd, funding_since_open_usd
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (wallet_address, venue, market, side) DO UPDATE SET
+			chain_id = EXCLUDED.chain_id,
+			symbol = EXCLUDED.symbol,
+			size = EXCLUDED.size,
+			size_usd = EXCLUDED.size_usd,
+			entry_price = EXCLUDED.entry_price,
+			mark_price = EXCLUDED.mark_price,
+			liquidation_price = EXCLUDED.liquidation_price,
+			leverage = EXCLUDED.leverage,
+			collateral_usd = EXCLUDED.collateral_usd,
+			unrealized_pnl_usd = EXCLUDED.unrealized_pnl_usd,
+			funding_since_open_usd = EXCLUDED.funding_since_open_usd,
+			opened_at = CASE WHEN derivative_positions.is_open THEN derivative_positions.opened_at ELSE NOW() END,
+			is_open = TRUE,
+			closed_at = NULL
+	`
+
+	keys := make([]string, 0, len(positions))
+	for _, p := range positions {
+		_, err := tx.Exec(ctx, upsert,
+			address, venue, p.ChainID, p.Market, p.Symbol, p.Side, p.Size, p.SizeUSD,
+			p.EntryPrice, p.MarkPrice, p.LiquidationPrice, p.Leverage, p.CollateralUSD,
+			p.UnrealizedPnLUSD, p.FundingSinceOpenUSD,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upsert derivative position %s %s: %w", p.Market, p.Side, err)
+		}
+		keys = append(keys, p.Market+":"+p.Side)
+	}
+
+	closeMissing := `
+		UPDATE derivative_positions
+		SET is_open = FALSE, closed_at = NOW()
+		WHERE wallet_address = $1 AND venue = $2 AND is_open
+		AND NOT (market || ':' || side = ANY($3))
+	`
+	if _, err := tx.Exec(ctx, closeMissing, address, venue, keys); err != nil {
+		return fmt.Errorf("failed to close derivative positions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit derivative positions: %w", err)
+	}
+
+	return nil
+}
+
+// GetOpenByAddresses returns the open positions of the given wallets, largest first
+func (r *derivativePositionRepository) GetOpenByAddresses(ctx context.Context, addresses []string) ([]*models.DerivativePosition, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	lowered := make([]string, len(addresses))
+	for i, address := range addresses {
+		lowered[i] = strings.ToLower(address)
+	}
+
+	query := `
+		SELECT ` + DerivativePositionColumns + `
+		FROM derivative_positions
+		WHERE wallet_address = ANY($1) AND is_open
+		ORDER BY size_usd DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, lowered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get derivative positions: %w", err)
+	}
+	defer rows.Close()
+
+	return ScanDerivativePositions(rows)
+}
+
+// InsertFundingPayments stores funding settlements, skipping ones already stored
+func (r *derivativePositionRepository) InsertFundingPayments(ctx context.Context, payments []*models.FundingPayment) (int64, error) {
+	if len(payments) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO derivative_funding_payments (
+			wallet_address, venue, market, amount_usd, funding_rate, position_size, paid_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (wallet_address, venue, market, paid_at) DO NOTHING
+	`
+
+	var inserted int64
+	for _, p := range payments {
+		tag, err := r.db.Exec(ctx, query, strings.ToLower(p.WalletAddress), p.Venue, p.Market, p.AmountUSD, p.FundingRate, p.PositionSize, p.PaidAt)
+		if err != nil {
+			return inserted, fmt.Errorf("failed to insert funding payment: %w", err)
+		}
+		inserted += tag.RowsAffected()
+	}
+
+	return inserted, nil
+}
+
+// GetLatestFundingTime returns when the wallet's most recent stored funding payment on
+// the venue settled, or nil if none is stored
+func (r *derivativePositionRepository) GetLatestFundingTime(ctx context.Context, address, venue string) (*time.Time, error) {
+	var latest *time.Time
+	err := r.db.QueryRow(ctx,
+		`SELECT MAX(paid_at) FROM derivative_funding_payments WHERE wallet_address = $1 AND venue = $2`,
+		strings.ToLower(address), venue,
+	).Scan(&latest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest funding time: %w", err)
+	}
+
+	return latest, nil
+}
+
+// DerivativePositionColumns are the columns ScanDerivativePositions expects, in order
+const DerivativePositionColumns = `
+	id, wallet_address, venue, chain_id, market, symbol, side, size::float8, size_usd::float8,
+	entry_price::float8, mark_price::float8, liquidation_price::float8, leverage::float8,
+	collateral_usd::float8, unrealized_pnl_usd::float8, funding_since_open_usd::float8,
+	is_open, opened_at, closed_at, updated_at`
+
+// ScanDerivativePositions scans rows selected with DerivativePositionColumns
+func ScanDerivativePositions(rows pgx.Rows) ([]*models.DerivativePosition, error) {
+	var positions []*models.DerivativePosition
+	for rows.Next() {
+		var p models.DerivativePosition
+		err := rows.Scan(
+			&p.ID,
+			&p.WalletAddress,
+			&p.Venue,
+			&p.ChainID,
+			&p.Market,
+			&p.Symbol,
+			&p.Side,
+			&p.Size,
+			&p.SizeUSD,
+			&p.EntryPrice,
+			&p.MarkPrice,
+			&p.LiquidationPrice,
+			&p.Leverage,
+			&p.CollateralUSD,
+			&p.UnrealizedPnLUSD,
+			&p.FundingSinceOpenUSD,
+			&p.IsOpen,
+			&p.OpenedAt,
+			&p.ClosedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan derivative position: %w", err)
+		}
+		positions = append(positions, &p)
+	}
+
+	return positions, rows.Err()
+}
@@ -0,0 +1,200 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EmailRepository interface {
+	RecordDelivery(ctx context.Context, delivery *models.EmailDelivery) error
+	// UpdateDeliveryStatus applies a status the provider reported for a message,
+	// returning whether a delivery had the message ID. Complaints are final.
+	UpdateDeliveryStatus(ctx context.Context, provider, messageID, status string, detail *string) (bool, error)
+	// GetDeliveries lists the latest deliveries to an address, newest first
+	GetDeliveries(ctx context.Context, email string, limit int) ([]*models.EmailDelivery, error)
+
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+	// Suppress adds the address to the suppression list, reporting whether it wasn't
+	// on it already. An address keeps the reason it was first suppressed for.
+	Suppress(ctx context.Context, suppression *models.EmailSuppression) (bool, error)
+	// Unsuppress reports whether the address was on the list
+	Unsuppress(ctx context.Context, email string) (bool, error)
+	GetSuppressions(ctx context.Context) ([]*models.EmailSuppression, error)
+
+	// DisableEmailNotifications turns off email on the alerts and statements of every
+	// user with the address, returning how many settings were changed
+	DisableEmailNotifications(ctx context.Context, email string) (int64, error)
+}
+
+type emailRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmailRepository(db *pgxpool.Pool) EmailRepository {
+	return &emailRepository{db: db}
+}
+
+const emailDeliveryColumns = `id, template, to_address, subject, provider, provider_message_id, status, attempts, error, created_at, updated_at`
+
+func scanEmailDelivery(row pgx.Row) (*models.EmailDelivery, error) {
+	var d models.EmailDelivery
+	err := row.Scan(
+		&d.ID,
+		&d.Template,
+		&d.To,
+		&d.Subject,
+		&d.Provider,
+		&d.ProviderMessageID,
+		&d.Status,
+		&d.Attempts,
+		&d.Error,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *emailRepository) RecordDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	query := `
+		INSERT INTO email_deliveries (template, to_address, subject, provider, provider_message_id, status, attempts, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(ctx, query,
+		delivery.Template, delivery.To, delivery.Subject, delivery.Provider,
+		delivery.ProviderMessageID, delivery.Status, delivery.Attempts, delivery.Error,
+	).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record email delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *emailRepository) UpdateDeliveryStatus(ctx context.Context, provider, messageID, status string, detail *string) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE email_deliveries SET status = $3, error = COALESCE($4, error)
+		WHERE provider = $1 AND provider_message_id = $2 AND status <> $5`,
+		provider, messageID, status, detail, models.EmailStatusComplained)
+	if err != nil {
+		return false, fmt.Errorf("failed to update email delivery: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *emailRepository) GetDeliveries(ctx context.Context, email string, limit int) ([]*models.EmailDelivery, error) {
+	query := `
+		SELECT ` + emailDeliveryColumns + `
+		FROM email_deliveries
+		WHERE LOWER(to_address) = LOWER($1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, email, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*models.EmailDelivery{}
+	for rows.Next() {
+		delivery, err := scanEmailDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *emailRepository) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	var suppressed bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM email_suppressions WHERE email = $1)`,
+		strings.ToLower(email)).Scan(&suppressed)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email suppression: %w", err)
+	}
+	return suppressed, nil
+}
+
+func (r *emailRepository) Suppress(ctx context.Context, suppression *models.EmailSuppression) (bool, error) {
+	suppression.Email = strings.ToLower(suppression.Email)
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO email_suppressions (email, reason, detail)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING created_at`,
+		suppression.Email, suppression.Reason, suppression.Detail,
+	).Scan(&suppression.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to suppress email: %w", err)
+	}
+	return true, nil
+}
+
+func (r *emailRepository) Unsuppress(ctx context.Context, email string) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM email_suppressions WHERE email = $1`, strings.ToLower(email))
+	if err != nil {
+		return false, fmt.Errorf("failed to unsuppress email: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *emailRepository) GetSuppressions(ctx context.Context) ([]*models.EmailSuppression, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT email, reason, detail, created_at
+		FROM email_suppressions
+		ORDER BY created_at DESC, email`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	suppressions := []*models.EmailSuppression{}
+	for rows.Next() {
+		var s models.EmailSuppression
+		if err := rows.Scan(&s.Email, &s.Reason, &s.Detail, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email suppression: %w", err)
+		}
+		suppressions = append(suppressions, &s)
+	}
+	return suppressions, rows.Err()
+}
+
+func (r *emailRepository) DisableEmailNotifications(ctx context.Context, email string) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	alerts, err := tx.Exec(ctx, `
+		UPDATE alerts SET notification = jsonb_set(notification, '{email}', 'false')
+		WHERE (notification->>'email')::boolean
+		  AND user_id IN (SELECT id FROM users WHERE LOWER(email) = LOWER($1))`, email)
+	if err != nil {
+		return 0, fmt.Errorf("failed to disable alert emails: %w", err)
+	}
+	statements, err := tx.Exec(ctx, `
+		UPDATE user_report_preferences SET email_enabled = false
+		WHERE email_enabled
+		  AND user_id IN (SELECT id FROM users WHERE LOWER(email) = LOWER($1))`, email)
+	if err != nil {
+		return 0, fmt.Errorf("failed to disable statement emails: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return alerts.RowsAffected() + statements.RowsAffected(), nil
+}
@@ -0,0 +1,40 @@
+package repos
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNotFound is wrapped by lookups that match no row, so callers can tell a missing
+// record from a failed query with errors.Is
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is wrapped by writes rejected by a unique constraint
+var ErrConflict = errors.New("conflict")
+
+// queryError wraps a failed lookup or write of what: no rows becomes ErrNotFound and a
+// unique violation ErrConflict, keeping the driver error in the chain. Other errors are
+// returned described but otherwise as they are.
+func queryError(err error, what string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%s %w: %w", what, ErrNotFound, err)
+	case isUniqueViolation(err, ""):
+		return fmt.Errorf("%s %w: %w", what, ErrConflict, err)
+	default:
+		return fmt.Errorf("failed to query %s: %w", what, err)
+	}
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation of the named
+// constraint, or of any constraint when it's empty
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		(constraint == "" || pgErr.ConstraintName == constraint)
+}
@@ -0,0 +1,33 @@
+package repos
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryError(t *testing.T) {
+	assert.NoError(t, queryError(nil, "user"))
+
+	err := queryError(pgx.ErrNoRows, "user")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "the driver error stays in the chain")
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "user not found: no rows in result set", err.Error())
+
+	err = queryError(&pgconn.PgError{Code: "23505", ConstraintName: "users_address_key"}, "user")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	// A broken connection is neither, so callers don't mistake it for a missing row
+	err = queryError(errors.New("connection refused"), "user")
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrConflict)
+
+	assert.ErrorIs(t, ErrWalletNotFound, ErrNotFound)
+	assert.ErrorIs(t, ErrYieldPoolNotFound, ErrNotFound)
+	assert.Equal(t, "yield pool not found", ErrYieldPoolNotFound.Error())
+}
@@ -0,0 +1,207 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EscalationRepository interface {
+	// SetAlertPolicy sets the policy the alert escalates through; nil clears it
+	SetAlertPolicy(ctx context.Context, alertID uuid.UUID, policyID *uuid.UUID) error
+	// Start opens an escalation for a trigger, reporting false when the trigger already
+	// has one. It updates escalation in place either way.
+	Start(ctx context.Context, escalation *models.AlertEscalation) (bool, error)
+	// GetByID returns nil when there's no such escalation
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AlertEscalation, error)
+	// GetByTeam returns the team's escalations, newest first, optionally of one status
+	GetByTeam(ctx context.Context, teamID uuid.UUID, status *string, limit int) ([]*models.AlertEscalation, error)
+	// GetDue returns open escalations whose next step is due at now, oldest first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*models.AlertEscalation, error)
+	// Advance moves an open escalation on from step to its next state, reporting false
+	// when it was acknowledged or advanced by someone else in the meantime
+	Advance(ctx context.Context, id uuid.UUID, step, nextStep int, nextStepAt time.Time, status string, lastError *string) (bool, error)
+	// Acknowledge closes an escalation of the team that's open or has run out of steps,
+	// reporting false when there's none unacknowledged with the ID
+	Acknowledge(ctx context.Context, teamID, id, userID uuid.UUID, at time.Time) (bool, error)
+}
+
+type escalationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEscalationRepository(db *pgxpool.Pool) EscalationRepository {
+	return &escalationRepository{db: db}
+}
+
+// Escalations are read with the trigger they escalate, from alertEscalationFrom
+const alertEscalationColumns = `e.id, e.alert_id, e.history_id, e.policy_id, e.team_id, e.status, e.next_step,
+	e.next_step_at, e.last_error, e.acknowledged_by, e.acknowledged_at, h.triggered_at, h.triggered_value,
+	e.created_at, e.updated_at`
+
+const alertEscalationFrom = ` alert_escalations e
+	JOIN alert_history h ON h.id = e.history_id AND h.triggered_at = e.history_triggered_at `
+
+func scanAlertEscalation(row pgx.Row) (*models.AlertEscalation, error) {
+	var e models.AlertEscalation
+	var valueJSON []byte
+	err := row.Scan(
+		&e.ID,
+		&e.AlertID,
+		&e.HistoryID,
+		&e.PolicyID,
+		&e.TeamID,
+		&e.Status,
+		&e.NextStep,
+		&e.NextStepAt,
+		&e.LastError,
+		&e.AcknowledgedBy,
+		&e.AcknowledgedAt,
+		&e.TriggeredAt,
+		&valueJSON,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(valueJSON) > 0 {
+		if err := json.Unmarshal(valueJSON, &e.TriggeredValue); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal triggered value: %w", err)
+		}
+	}
+	return &e, nil
+}
+
+func (r *escalationRepository) SetAlertPolicy(ctx context.Context, alertID uuid.UUID, policyID *uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `UPDATE alerts SET escalation_policy_id = $2, updated_at = NOW() WHERE id = $1`, alertID, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to set alert escalation policy: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlertNotFound
+	}
+	return nil
+}
+
+func (r *escalationRepository) Start(ctx context.Context, escalation *models.AlertEscalation) (bool, error) {
+	query := `
+		WITH e AS (
+			INSERT INTO alert_escalations (alert_id, history_id, history_triggered_at, policy_id, team_id, next_step_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (history_id) DO NOTHING
+			RETURNING *
+		)
+		SELECT ` + alertEscalationColumns + ` FROM e
+		JOIN alert_history h ON h.id = e.history_id AND h.triggered_at = e.history_triggered_at`
+
+	saved, err := scanAlertEscalation(r.db.QueryRow(ctx, query,
+		escalation.AlertID, escalation.HistoryID, escalation.TriggeredAt, escalation.PolicyID, escalation.TeamID, escalation.NextStepAt))
+	started := true
+	if err == pgx.ErrNoRows {
+		// A retried trigger; carry on with the escalation it already has
+		started = false
+		query = `SELECT ` + alertEscalationColumns + ` FROM` + alertEscalationFrom + `WHERE e.history_id = $1`
+		saved, err = scanAlertEscalation(r.db.QueryRow(ctx, query, escalation.HistoryID))
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to start escalation: %w", err)
+	}
+	*escalation = *saved
+	return started, nil
+}
+
+func (r *escalationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AlertEscalation, error) {
+	query := `SELECT ` + alertEscalationColumns + ` FROM` + alertEscalationFrom + `WHERE e.id = $1`
+
+	escalation, err := scanAlertEscalation(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation: %w", err)
+	}
+	return escalation, nil
+}
+
+func (r *escalationRepository) GetByTeam(ctx context.Context, teamID uuid.UUID, status *string, limit int) ([]*models.AlertEscalation, error) {
+	query := `
+		SELECT ` + alertEscalationColumns + `
+		FROM` + alertEscalationFrom + `
+		WHERE e.team_id = $1 AND ($2::text IS NULL OR e.status = $2)
+		ORDER BY e.created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, teamID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalations: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAlertEscalations(rows)
+}
+
+func (r *escalationRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*models.AlertEscalation, error) {
+	query := `
+		SELECT ` + alertEscalationColumns + `
+		FROM` + alertEscalationFrom + `
+		WHERE e.status = 'open' AND e.next_step_at <= $1
+		ORDER BY e.next_step_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due escalations: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAlertEscalations(rows)
+}
+
+func scanAlertEscalations(rows pgx.Rows) ([]*models.AlertEscalation, error) {
+	escalations := []*models.AlertEscalation{}
+	for rows.Next() {
+		escalation, err := scanAlertEscalation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation: %w", err)
+		}
+		escalations = append(escalations, escalation)
+	}
+	return escalations, rows.Err()
+}
+
+func (r *escalationRepository) Advance(ctx context.Context, id uuid.UUID, step, nextStep int, nextStepAt time.Time, status string, lastError *string) (bool, error) {
+	query := `
+		UPDATE alert_escalations
+		SET next_step = $3, next_step_at = $4, status = $5, last_error = COALESCE($6, last_error)
+		WHERE id = $1 AND next_step = $2 AND status = 'open'
+	`
+
+	result, err := r.db.Exec(ctx, query, id, step, nextStep, nextStepAt, status, lastError)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance escalation: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *escalationRepository) Acknowledge(ctx context.Context, teamID, id, userID uuid.UUID, at time.Time) (bool, error) {
+	query := `
+		UPDATE alert_escalations
+		SET status = 'acknowledged', acknowledged_by = $3, acknowledged_at = $4
+		WHERE id = $1 AND team_id = $2 AND status IN ('open', 'exhausted')
+	`
+
+	result, err := r.db.Exec(ctx, query, id, teamID, userID, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to acknowledge escalation: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,304 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExchangeRepository interface {
+	GetAccounts(ctx context.Context, userID uuid.UUID) ([]*models.ExchangeAccount, error)
+	GetAllAccounts(ctx context.Context) ([]*models.ExchangeAccount, error)
+	AddAccount(ctx context.Context, account *models.ExchangeAccount) error
+	DeleteAccount(ctx context.Context, userID, accountID uuid.UUID) (bool, error)
+	MarkSynced(ctx context.Context, accountID uuid.UUID, syncedAt time.Time, syncErr *string) error
+	ReplaceBalances(ctx context.Context, accountID uuid.UUID, balances []*models.ExchangeAssetBalance) error
+	GetBalances(ctx context.Context, userID uuid.UUID) ([]*models.ExchangeAssetBalance, error)
+	InsertTrades(ctx context.Context, accountID uuid.UUID, trades []*models.ExchangeTrade) error
+	GetTrades(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ExchangeTrade, error)
+	GetAssetTradesBetween(ctx context.Context, userID uuid.UUID, asset string, from, to time.Time) ([]*models.ExchangeTrade, error)
+}
+
+type exchangeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExchangeRepository(db *pgxpool.Pool) ExchangeRepository {
+	return &exchangeRepository{db: db}
+}
+
+const exchangeAccountColumns = `id, user_id, exchange, label, key_hint, encrypted_credentials,
+	last_synced_at, last_error, created_at`
+
+const exchangeTradeColumns = `t.id, t.account_id, a.exchange, t.trade_id, t.base_asset, t.quote_asset,
+	t.side, t.quantity::float8, t.price::float8, t.fee::float8, COALESCE(t.fee_asset, ''),
+	t.quote_price_usd::float8, t.executed_at`
+
+func (r *exchangeRepository) GetAccounts(ctx context.Context, userID uuid.UUID) ([]*models.ExchangeAccount, error) {
+	query := `SELECT ` + exchangeAccountColumns + ` FROM exchange_accounts WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange accounts: %w", err)
+	}
+	return scanExchangeAccounts(rows)
+}
+
+// GetAllAccounts returns every connected account, least recently synced first
+func (r *exchangeRepository) GetAllAccounts(ctx context.Context) ([]*models.ExchangeAccount, error) {
+	query := `SELECT ` + exchangeAccountColumns + ` FROM exchange_accounts ORDER BY last_synced_at NULLS FIRST`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange accounts: %w", err)
+	}
+	return scanExchangeAccounts(rows)
+}
+
+// AddAccount connects an exchange account; connecting the same key again replaces its
+// credentials and label
+func (r *exchangeRepository) AddAccount(ctx context.Context, account *models.ExchangeAccount) error {
+	query := `
+		INSERT INTO exchange_accounts (user_id, exchange, label, key_hint, encrypted_credentials)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, exchange, key_hint) DO UPDATE SET
+			label = EXCLUDED.label,
+			encrypted_credentials = EXCLUDED.encrypted_credentials,
+			last_error = NULL
+		RETURNING id, last_synced_at, last_error, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		account.UserID,
+		account.Exchange,
+		account.Label,
+		account.KeyHint,
+		account.EncryptedCredentials,
+	).Scan(&account.ID, &account.LastSyncedAt, &account.LastError, &account.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add exchange account: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount disconnects an account, dropping its balances and trades, and reports
+// whether the user had connected it
+func (r *exchangeRepository) DeleteAccount(ctx context.Context, userID, accountID uuid.UUID) (bool, error) {
+	query := `DELETE FROM exchange_accounts WHERE user_id = $1 AND id = $2`
+
+	result, err := r.db.Exec(ctx, query, userID, accountID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete exchange account: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// MarkSynced records a sync attempt; syncErr is nil when it succeeded, and a failed
+// attempt leaves last_synced_at so the next one resumes from the last success
+func (r *exchangeRepository) MarkSynced(ctx context.Context, accountID uuid.UUID, syncedAt time.Time, syncErr *string) error {
+	query := `
+		UPDATE exchange_accounts
+		SET last_synced_at = CASE WHEN $3::text IS NULL THEN $2 ELSE last_synced_at END,
+			last_error = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, accountID, syncedAt, syncErr); err != nil {
+		return fmt.Errorf("failed to mark exchange account synced: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceBalances swaps an account's stored balances for the given ones
+func (r *exchangeRepository) ReplaceBalances(ctx context.Context, accountID uuid.UUID, balances []*models.ExchangeAssetBalance) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM exchange_balances WHERE account_id = $1`, accountID); err != nil {
+		return fmt.Errorf("failed to clear exchange balances: %w", err)
+	}
+
+	query := `
+		INSERT INTO exchange_balances (account_id, asset, free, locked, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (account_id, asset) DO UPDATE SET
+			free = exchange_balances.free + EXCLUDED.free,
+			locked = exchange_balances.locked + EXCLUDED.locked
+	`
+	for _, balance := range balances {
+		if _, err := tx.Exec(ctx, query, accountID, balance.Asset, balance.Free, balance.Locked); err != nil {
+			return fmt.Errorf("failed to store exchange balance %s: %w", balance.Asset, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit exchange balances: %w", err)
+	}
+	return nil
+}
+
+// GetBalances returns the stored balances of all the user's accounts
+func (r *exchangeRepository) GetBalances(ctx context.Context, userID uuid.UUID) ([]*models.ExchangeAssetBalance, error) {
+	query := `
+		SELECT b.account_id, b.asset, b.free::float8, b.locked::float8, b.updated_at
+		FROM exchange_balances b
+		JOIN exchange_accounts a ON a.id = b.account_id
+		WHERE a.user_id = $1
+		ORDER BY b.account_id, b.asset
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []*models.ExchangeAssetBalance
+	for rows.Next() {
+		var b models.ExchangeAssetBalance
+		if err := rows.Scan(&b.AccountID, &b.Asset, &b.Free, &b.Locked, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange balance: %w", err)
+		}
+		balances = append(balances, &b)
+	}
+
+	return balances, rows.Err()
+}
+
+// InsertTrades stores an account's trades; ones already stored are left as is
+func (r *exchangeRepository) InsertTrades(ctx context.Context, accountID uuid.UUID, trades []*models.ExchangeTrade) error {
+	query := `
+		INSERT INTO exchange_trades (
+			account_id, trade_id, base_asset, quote_asset, side, quantity, price,
+			fee, fee_asset, quote_price_usd, executed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11)
+		ON CONFLICT (account_id, trade_id) DO NOTHING
+	`
+
+	for _, trade := range trades {
+		trade.AccountID = accountID
+		_, err := r.db.Exec(ctx, query,
+			trade.AccountID,
+			trade.TradeID,
+			trade.BaseAsset,
+			trade.QuoteAsset,
+			trade.Side,
+			trade.Quantity,
+			trade.Price,
+			trade.Fee,
+			trade.FeeAsset,
+			trade.QuotePriceUSD,
+			trade.ExecutedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert exchange trade %s: %w", trade.TradeID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetTrades returns a page of the user's trades across accounts, newest first
+func (r *exchangeRepository) GetTrades(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ExchangeTrade, error) {
+	query := `
+		SELECT ` + exchangeTradeColumns + `
+		FROM exchange_trades t
+		JOIN exchange_accounts a ON a.id = t.account_id
+		WHERE a.user_id = $1
+		ORDER BY t.executed_at DESC, t.trade_id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange trades: %w", err)
+	}
+	return scanExchangeTrades(rows)
+}
+
+// GetAssetTradesBetween returns the user's trades that bought or sold an asset, on either
+// side of the pair, in a time range, oldest first
+func (r *exchangeRepository) GetAssetTradesBetween(ctx context.Context, userID uuid.UUID, asset string, from, to time.Time) ([]*models.ExchangeTrade, error) {
+	query := `
+		SELECT ` + exchangeTradeColumns + `
+		FROM exchange_trades t
+		JOIN exchange_accounts a ON a.id = t.account_id
+		WHERE a.user_id = $1
+			AND (t.base_asset = $2 OR t.quote_asset = $2)
+			AND t.executed_at BETWEEN $3 AND $4
+		ORDER BY t.executed_at, t.trade_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, asset, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange trades: %w", err)
+	}
+	return scanExchangeTrades(rows)
+}
+
+func scanExchangeAccounts(rows pgx.Rows) ([]*models.ExchangeAccount, error) {
+	defer rows.Close()
+
+	var accounts []*models.ExchangeAccount
+	for rows.Next() {
+		var a models.ExchangeAccount
+		err := rows.Scan(
+			&a.ID,
+			&a.UserID,
+			&a.Exchange,
+			&a.Label,
+			&a.KeyHint,
+			&a.EncryptedCredentials,
+			&a.LastSyncedAt,
+			&a.LastError,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exchange account: %w", err)
+		}
+		accounts = append(accounts, &a)
+	}
+
+	return accounts, rows.Err()
+}
+
+func scanExchangeTrades(rows pgx.Rows) ([]*models.ExchangeTrade, error) {
+	defer rows.Close()
+
+	var trades []*models.ExchangeTrade
+	for rows.Next() {
+		var t models.ExchangeTrade
+		err := rows.Scan(
+			&t.ID,
+			&t.AccountID,
+			&t.Exchange,
+			&t.TradeID,
+			&t.BaseAsset,
+			&t.QuoteAsset,
+			&t.Side,
+			&t.Quantity,
+			&t.Price,
+			&t.Fee,
+			&t.FeeAsset,
+			&t.QuotePriceUSD,
+			&t.ExecutedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exchange trade: %w", err)
+		}
+		trades = append(trades, &t)
+	}
+
+	return trades, rows.Err()
+}
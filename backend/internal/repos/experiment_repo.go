@@ -0,0 +1,101 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExperimentRepository keeps who was exposed to which experiment variant and what they
+// did after
+type ExperimentRepository interface {
+	// RecordExposure records the user's first exposure to the experiment; later ones are
+	// ignored
+	RecordExposure(ctx context.Context, exposure *models.ExperimentExposure) error
+	// RecordConversion records the metric for every experiment the user was exposed to
+	// by the time, once per experiment
+	RecordConversion(ctx context.Context, userID uuid.UUID, metric string, at time.Time) error
+	// GetResults counts each variant's exposures and converted users per metric, with
+	// metrics in name order. Rates are left to the caller.
+	GetResults(ctx context.Context, experiment string) ([]models.ExperimentVariantResult, error)
+}
+
+type experimentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExperimentRepository(db *pgxpool.Pool) ExperimentRepository {
+	return &experimentRepository{db: db}
+}
+
+func (r *experimentRepository) RecordExposure(ctx context.Context, exposure *models.ExperimentExposure) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO experiment_exposures (experiment, user_id, variant, exposed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (experiment, user_id) DO NOTHING`,
+		exposure.Experiment, exposure.UserID, exposure.Variant, exposure.ExposedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record experiment exposure: %w", err)
+	}
+	return nil
+}
+
+func (r *experimentRepository) RecordConversion(ctx context.Context, userID uuid.UUID, metric string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO experiment_conversions (experiment, user_id, metric, converted_at)
+		SELECT experiment, user_id, $2, $3
+		FROM experiment_exposures
+		WHERE user_id = $1 AND exposed_at <= $3
+		ON CONFLICT (experiment, user_id, metric) DO NOTHING`,
+		userID, metric, at)
+	if err != nil {
+		return fmt.Errorf("failed to record experiment conversion: %w", err)
+	}
+	return nil
+}
+
+func (r *experimentRepository) GetResults(ctx context.Context, experiment string) ([]models.ExperimentVariantResult, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT e.variant, COUNT(*) AS exposures, NULL AS metric
+		FROM experiment_exposures e
+		WHERE e.experiment = $1
+		GROUP BY e.variant
+		UNION ALL
+		SELECT e.variant, COUNT(*), c.metric
+		FROM experiment_conversions c
+		JOIN experiment_exposures e ON e.experiment = c.experiment AND e.user_id = c.user_id
+		WHERE c.experiment = $1
+		GROUP BY e.variant, c.metric
+		ORDER BY 1, 3 NULLS FIRST`,
+		experiment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment results: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.ExperimentVariantResult{}
+	for rows.Next() {
+		var variant string
+		var count int
+		var metric *string
+		if err := rows.Scan(&variant, &count, &metric); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment result: %w", err)
+		}
+		if metric == nil {
+			results = append(results, models.ExperimentVariantResult{
+				Variant:     variant,
+				Exposures:   count,
+				Conversions: []models.ExperimentConversion{},
+			})
+			continue
+		}
+		// Exposures sort first, so the variant's result is the last one added
+		last := &results[len(results)-1]
+		last.Conversions = append(last.Conversions, models.ExperimentConversion{Metric: *metric, Users: count})
+	}
+	return results, rows.Err()
+}
\ No newline at end of file
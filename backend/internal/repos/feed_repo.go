@@ -0,0 +1,239 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/feeds"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrFeedSourceExists is returned when a source with the URL is already registered
+var ErrFeedSourceExists = errors.New("feed source already exists")
+
+// FeedItemFilter selects news feed items, newest first
+type FeedItemFilter struct {
+	// HoldingsOf narrows the feed to sources about the protocols the user has active
+	// positions in or the tokens in their wallets
+	HoldingsOf *uuid.UUID
+	// Before pages back from the oldest item already shown
+	Before *time.Time
+	Limit  int
+}
+
+type FeedRepository interface {
+	CreateSource(ctx context.Context, source *models.FeedSource) error
+	GetSources(ctx context.Context) ([]*models.FeedSource, error)
+	GetEnabledSources(ctx context.Context) ([]*models.FeedSource, error)
+	// DeleteSource reports whether there was a source with the ID; its items go with it
+	DeleteSource(ctx context.Context, id uuid.UUID) (bool, error)
+	// MarkFetched records a fetch of the source and its error, if any
+	MarkFetched(ctx context.Context, id uuid.UUID, fetchErr error) error
+	// AddItems stores the items not seen before, from this source by GUID or from any
+	// source by URL, and returns how many were new
+	AddItems(ctx context.Context, sourceID uuid.UUID, items []feeds.Item) (int, error)
+	GetItems(ctx context.Context, filter FeedItemFilter) ([]*models.FeedItem, error)
+	// GetItem returns the item with the ID, or nil when there's none
+	GetItem(ctx context.Context, id uuid.UUID) (*models.FeedItem, error)
+	DeleteItemsOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+type feedRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFeedRepository(db *pgxpool.Pool) FeedRepository {
+	return &feedRepository{db: db}
+}
+
+const feedSourceColumns = `id, name, kind, url, protocol_id, tokens, enabled, last_fetched_at, last_error, created_at, updated_at`
+
+func scanFeedSource(row pgx.Row) (*models.FeedSource, error) {
+	var s models.FeedSource
+	err := row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.Kind,
+		&s.URL,
+		&s.ProtocolID,
+		&s.Tokens,
+		&s.Enabled,
+		&s.LastFetchedAt,
+		&s.LastError,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *feedRepository) CreateSource(ctx context.Context, source *models.FeedSource) error {
+	query := `
+		INSERT INTO feed_sources (name, kind, url, protocol_id, tokens, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + feedSourceColumns
+
+	created, err := scanFeedSource(r.db.QueryRow(ctx, query,
+		source.Name, source.Kind, source.URL, source.ProtocolID, source.Tokens, source.Enabled))
+	if isUniqueViolation(err, "feed_sources_url_key") {
+		return ErrFeedSourceExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create feed source: %w", err)
+	}
+	*source = *created
+	return nil
+}
+
+func (r *feedRepository) GetSources(ctx context.Context) ([]*models.FeedSource, error) {
+	return r.getSources(ctx, `SELECT `+feedSourceColumns+` FROM feed_sources ORDER BY name, id`)
+}
+
+func (r *feedRepository) GetEnabledSources(ctx context.Context) ([]*models.FeedSource, error) {
+	return r.getSources(ctx, `SELECT `+feedSourceColumns+` FROM feed_sources WHERE enabled ORDER BY last_fetched_at NULLS FIRST, id`)
+}
+
+func (r *feedRepository) getSources(ctx context.Context, query string) ([]*models.FeedSource, error) {
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []*models.FeedSource{}
+	for rows.Next() {
+		source, err := scanFeedSource(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed source: %w", err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+func (r *feedRepository) DeleteSource(ctx context.Context, id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM feed_sources WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete feed source: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *feedRepository) MarkFetched(ctx context.Context, id uuid.UUID, fetchErr error) error {
+	var lastError *string
+	if fetchErr != nil {
+		message := fetchErr.Error()
+		lastError = &message
+	}
+	_, err := r.db.Exec(ctx, `UPDATE feed_sources SET last_fetched_at = NOW(), last_error = $2 WHERE id = $1`, id, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to mark feed source fetched: %w", err)
+	}
+	return nil
+}
+
+func (r *feedRepository) AddItems(ctx context.Context, sourceID uuid.UUID, items []feeds.Item) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, item := range items {
+		var summary *string
+		if item.Summary != "" {
+			summary = &item.Summary
+		}
+		batch.Queue(`
+			INSERT INTO feed_items (source_id, guid, url, title, summary, published_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT DO NOTHING`,
+			sourceID, item.GUID, item.URL, item.Title, summary, item.PublishedAt)
+	}
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	added := 0
+	for range items {
+		tag, err := results.Exec()
+		if err != nil {
+			return added, fmt.Errorf("failed to add feed item: %w", err)
+		}
+		added += int(tag.RowsAffected())
+	}
+	return added, nil
+}
+
+func (r *feedRepository) GetItems(ctx context.Context, filter FeedItemFilter) ([]*models.FeedItem, error) {
+	query := `
+		SELECT i.id, i.title, i.url, i.summary, i.published_at, s.name, p.slug, s.tokens
+		FROM feed_items i
+		JOIN feed_sources s ON s.id = i.source_id
+		LEFT JOIN protocols p ON p.id = s.protocol_id
+		WHERE ($1::uuid IS NULL
+		       OR s.protocol_id IN (
+		           SELECT yp.protocol_id FROM yield_positions yp
+		           WHERE yp.user_id = $1 AND yp.is_active AND yp.protocol_id IS NOT NULL)
+		       OR s.tokens && ARRAY(
+		           SELECT DISTINCT UPPER(t.symbol)
+		           FROM wallets w
+		           JOIN balances b ON b.wallet_id = w.id AND b.balance > 0
+		           JOIN tokens t ON t.id = b.token_id
+		           WHERE w.user_id = $1 AND w.removed_at IS NULL)::text[])
+		  AND ($2::timestamptz IS NULL OR i.published_at < $2)
+		ORDER BY i.published_at DESC, i.id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, filter.HoldingsOf, filter.Before, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.FeedItem{}
+	for rows.Next() {
+		var item models.FeedItem
+		err := rows.Scan(&item.ID, &item.Title, &item.URL, &item.Summary, &item.PublishedAt, &item.SourceName, &item.Protocol, &item.Tokens)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed item: %w", err)
+		}
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func (r *feedRepository) GetItem(ctx context.Context, id uuid.UUID) (*models.FeedItem, error) {
+	query := `
+		SELECT i.id, i.title, i.url, i.summary, i.published_at, s.name, p.slug, s.tokens
+		FROM feed_items i
+		JOIN feed_sources s ON s.id = i.source_id
+		LEFT JOIN protocols p ON p.id = s.protocol_id
+		WHERE i.id = $1
+	`
+
+	var item models.FeedItem
+	err := r.db.QueryRow(ctx, query, id).Scan(&item.ID, &item.Title, &item.URL, &item.Summary, &item.PublishedAt, &item.SourceName, &item.Protocol, &item.Tokens)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed item: %w", err)
+	}
+	return &item, nil
+}
+
+func (r *feedRepository) DeleteItemsOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM feed_items WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old feed items: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -10,6 +10,8 @@ import (
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
+	// GetByAddress, GetByID and GetByEmail return an error wrapping ErrNotFound when no
+	// user matches
 	GetByAddress(ctx context.Context, address string) (*models.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
@@ -24,9 +26,16 @@ type UserRepository interface {
 type WalletRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Wallet, error)
 	GetByAddress(ctx context.Context, address string, chainID int) (*models.Wallet, error)
+	// GetAllByAddresses lists every user's EVM wallets tracking any of the addresses on
+	// the chain
+	GetAllByAddresses(ctx context.Context, addresses []string, chainID int) ([]*models.Wallet, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
 	Create(ctx context.Context, userID uuid.UUID, address string, chainID int, label *string, isPrimary bool) (*models.Wallet, error)
 	Update(ctx context.Context, id, userID uuid.UUID, label *string) (*models.Wallet, error)
+	SetVisibility(ctx context.Context, id, userID uuid.UUID, visibility string) (*models.Wallet, error)
+	// GetUndetectedAccounts lists EVM wallets whose account type isn't known yet, oldest first
+	GetUndetectedAccounts(ctx context.Context, limit int) ([]*models.Wallet, error)
+	SetAccountType(ctx context.Context, id uuid.UUID, accountType string) error
 	SetPrimary(ctx context.Context, userID, walletID uuid.UUID) error
 	Delete(ctx context.Context, id, userID uuid.UUID) error
 }
@@ -39,6 +48,8 @@ type TokenRepository interface {
 	Search(ctx context.Context, query string, chainID *int) ([]*models.Token, error)
 	Create(ctx context.Context, token *models.Token) (*models.Token, error)
 	UpdatePrice(ctx context.Context, address string, chainID int, priceUSD, priceChange24h, marketCap float64) (*models.Token, error)
+	GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error)
+	UpsertTokens(ctx context.Context, tokens []*models.Token) error
 }
 
 // TransactionRepository defines the interface for transaction data access
@@ -49,6 +60,17 @@ type TransactionRepository interface {
 	Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
 	UpdateStatus(ctx context.Context, hash, status string, blockNumber, gasUsed int64, gasFeeUSD float64) (*models.Transaction, error)
 	LinkToUser(ctx context.Context, userID, transactionID, walletID uuid.UUID) error
+	Search(ctx context.Context, userID uuid.UUID, filters TransactionSearchFilters) ([]*models.Transaction, error)
+	GetPending(ctx context.Context, limit int) ([]*models.Transaction, error)
+	GetUnfinalizedByAddress(ctx context.Context, address string, chainID int) ([]*models.Transaction, error)
+	// GetByHashes returns the stored transactions among the hashes on the chain, keyed by
+	// lowercase hash
+	GetByHashes(ctx context.Context, chainID int, hashes []string) (map[string]*models.Transaction, error)
+	UpdateConfirmations(ctx context.Context, id uuid.UUID, chainID int, status string, confirmations int64, blockNumber *int64, blockHash *string) error
+	GetOwnerIDs(ctx context.Context, transactionID uuid.UUID, chainID int) ([]uuid.UUID, error)
+	GetFeeSpend(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]models.StatementFeeSpend, error)
+	GetProtocolInteractions(ctx context.Context, userID uuid.UUID, address string) ([]*models.ProtocolInteraction, error)
+	GetGasUsage(ctx context.Context, userID uuid.UUID, address string, from, to time.Time) ([]models.GasUsage, error)
 }
 
 // TransactionFilters for querying transactions
@@ -61,8 +83,34 @@ type TransactionFilters struct {
 	Offset    int
 }
 
+// TransactionSearchFilters for searching a user's transactions. Value bounds are
+// inclusive and in base units; the time range is [From, To).
+type TransactionSearchFilters struct {
+	ChainIDs          []int
+	Types             []string
+	Statuses          []string
+	Token             *string
+	CounterpartyLabel *string
+	MinValue          *string
+	MaxValue          *string
+	From              *time.Time
+	To                *time.Time
+	Text              []string
+	Tags              []string // annotation tags, any of which must be present
+	Cursor            *TransactionCursor
+	Limit             int
+}
+
+// TransactionCursor is the position after which the next page of results starts
+type TransactionCursor struct {
+	Timestamp time.Time
+	ID        uuid.UUID
+}
+
 // ProtocolRepository defines the interface for protocol data access
 type ProtocolRepository interface {
+	// GetByID and GetBySlug return an error wrapping ErrNotFound when no active protocol
+	// matches
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Protocol, error)
 	GetBySlug(ctx context.Context, slug string) (*models.Protocol, error)
 	GetAll(ctx context.Context, filters ProtocolFilters) ([]*models.Protocol, error)
@@ -77,10 +125,13 @@ type ProtocolRepository interface {
 
 // YieldPoolRepository defines the interface for yield pool data access
 type YieldPoolRepository interface {
+	// GetByID and GetByPoolID return an error wrapping ErrNotFound when no pool matches
 	GetByID(ctx context.Context, id uuid.UUID) (*models.YieldPool, error)
 	GetByPoolID(ctx context.Context, poolID string) (*models.YieldPool, error)
 	GetAll(ctx context.Context, filters YieldPoolFilters) ([]*models.YieldPool, error)
 	Count(ctx context.Context, filters YieldPoolFilters) (int64, error)
+	// Search returns a page of pools matching the search and the total number that match
+	Search(ctx context.Context, search YieldPoolSearch) ([]*models.YieldPool, int64, error)
 	GetByProtocol(ctx context.Context, protocolID uuid.UUID, activeOnly bool) ([]*models.YieldPool, error)
 	GetByChain(ctx context.Context, chainID int) ([]*models.YieldPool, error)
 	GetTopByTVL(ctx context.Context, limit int) ([]*models.YieldPool, error)
@@ -92,6 +143,7 @@ type YieldPoolRepository interface {
 
 // YieldPositionRepository defines the interface for position data access
 type YieldPositionRepository interface {
+	// GetByID returns an error wrapping ErrNotFound when no position matches
 	GetByID(ctx context.Context, id uuid.UUID) (*models.YieldPosition, error)
 	GetByUser(ctx context.Context, userID uuid.UUID, filters PositionFilters) ([]*models.YieldPosition, error)
 	GetByWallet(ctx context.Context, walletID uuid.UUID, activeOnly bool) ([]*models.YieldPosition, error)
@@ -133,6 +185,29 @@ type YieldPoolFilters struct {
 	Offset        int
 }
 
+// YieldPoolSearch for searching pools. Empty and nil fields don't filter; list fields
+// match any of their values.
+type YieldPoolSearch struct {
+	// Text matches words, as prefixes, in the pool name, symbol or protocol
+	Text           string
+	ChainIDs       []int
+	RiskLevels     []string
+	ProtocolSlugs  []string
+	MinAPY         *float64
+	MaxAPY         *float64
+	MinTVL         *float64
+	MaxTVL         *float64
+	StablecoinOnly bool
+	IsActive       *bool
+	// CreatedAfter keeps pools first seen after the time
+	CreatedAfter *time.Time
+	// SortBy is a field IsYieldPoolSortField accepts; nulls sort last either way
+	SortBy  string
+	SortAsc bool
+	Limit   int
+	Offset  int
+}
+
 // PositionFilters for querying positions
 type PositionFilters struct {
 	IsActive *bool
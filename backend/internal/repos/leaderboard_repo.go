@@ -0,0 +1,259 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrLeaderboardAliasTaken is returned when another participant already has the alias
+var ErrLeaderboardAliasTaken = errors.New("leaderboard alias already taken")
+
+// LeaderboardRepository keeps the leaderboard participants and computes the boards and
+// platform stats. Every query leaves out private and testnet wallets.
+type LeaderboardRepository interface {
+	// GetParticipant returns nil when the user hasn't opted in
+	GetParticipant(ctx context.Context, userID uuid.UUID) (*models.LeaderboardParticipant, error)
+	// OptIn adds the user under alias, keeping the existing participant if there is one
+	OptIn(ctx context.Context, userID uuid.UUID, alias string) (*models.LeaderboardParticipant, error)
+	OptOut(ctx context.Context, userID uuid.UUID) error
+	// ListParticipants lists every participant, newest first
+	ListParticipants(ctx context.Context) ([]*models.LeaderboardParticipant, error)
+	// SetHidden reports whether the user is a participant
+	SetHidden(ctx context.Context, userID uuid.UUID, hidden bool) (bool, error)
+	// TopReturns ranks the visible participants by the change in value of their wallets
+	// between the first snapshot since the given time and the latest one
+	TopReturns(ctx context.Context, since time.Time, minValueUSD float64, limit int) ([]*models.LeaderboardEntry, error)
+	// TopDiversified ranks the visible participants by the effective number of tokens
+	// they hold, the inverse of the Herfindahl index of their holdings
+	TopDiversified(ctx context.Context, minValueUSD float64, limit int) ([]*models.LeaderboardEntry, error)
+	// GetPlatformStats totals the tracked wallets of every user and lists the tokens most
+	// users hold, among those held by at least minHolders users
+	GetPlatformStats(ctx context.Context, minHolders, tokenLimit int) (*models.PlatformStats, error)
+}
+
+type leaderboardRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLeaderboardRepository(db *pgxpool.Pool) LeaderboardRepository {
+	return &leaderboardRepository{db: db}
+}
+
+const leaderboardParticipantColumns = `user_id, alias, hidden, opted_in_at`
+
+func scanLeaderboardParticipant(row pgx.Row) (*models.LeaderboardParticipant, error) {
+	var p models.LeaderboardParticipant
+	if err := row.Scan(&p.UserID, &p.Alias, &p.Hidden, &p.OptedInAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// sharedHoldingsQuery values every balance in the users' visible mainnet wallets at the
+// token's current price, falling back to the value stored at sync time
+const sharedHoldingsQuery = `
+	SELECT w.id AS wallet_id, w.user_id, t.id AS token_id, t.symbol,
+	       COALESCE(b.balance / POWER(10::numeric, t.decimals) * p.price_usd, b.balance_usd, 0) AS value_usd
+	FROM wallets w
+	JOIN balances b ON b.wallet_id = w.id AND b.balance > 0
+	JOIN tokens t ON t.id = b.token_id
+	LEFT JOIN token_prices_latest p ON p.token_id = t.id
+	WHERE NOT w.is_testnet AND w.visibility <> 'private' AND w.removed_at IS NULL
+`
+
+func (r *leaderboardRepository) GetParticipant(ctx context.Context, userID uuid.UUID) (*models.LeaderboardParticipant, error) {
+	query := `SELECT ` + leaderboardParticipantColumns + ` FROM leaderboard_participants WHERE user_id = $1`
+
+	participant, err := scanLeaderboardParticipant(r.db.QueryRow(ctx, query, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard participant: %w", err)
+	}
+	return participant, nil
+}
+
+func (r *leaderboardRepository) OptIn(ctx context.Context, userID uuid.UUID, alias string) (*models.LeaderboardParticipant, error) {
+	query := `
+		INSERT INTO leaderboard_participants (user_id, alias)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING ` + leaderboardParticipantColumns
+
+	participant, err := scanLeaderboardParticipant(r.db.QueryRow(ctx, query, userID, alias))
+	if err == pgx.ErrNoRows {
+		return r.GetParticipant(ctx, userID)
+	}
+	if isUniqueViolation(err, "leaderboard_participants_alias_key") {
+		return nil, ErrLeaderboardAliasTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add leaderboard participant: %w", err)
+	}
+	return participant, nil
+}
+
+func (r *leaderboardRepository) OptOut(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM leaderboard_participants WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to remove leaderboard participant: %w", err)
+	}
+	return nil
+}
+
+func (r *leaderboardRepository) ListParticipants(ctx context.Context) ([]*models.LeaderboardParticipant, error) {
+	query := `SELECT ` + leaderboardParticipantColumns + ` FROM leaderboard_participants ORDER BY opted_in_at DESC, user_id`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard participants: %w", err)
+	}
+	defer rows.Close()
+
+	participants := []*models.LeaderboardParticipant{}
+	for rows.Next() {
+		participant, err := scanLeaderboardParticipant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard participant: %w", err)
+		}
+		participants = append(participants, participant)
+	}
+	return participants, rows.Err()
+}
+
+func (r *leaderboardRepository) SetHidden(ctx context.Context, userID uuid.UUID, hidden bool) (bool, error) {
+	result, err := r.db.Exec(ctx, `UPDATE leaderboard_participants SET hidden = $2 WHERE user_id = $1`, userID, hidden)
+	if err != nil {
+		return false, fmt.Errorf("failed to update leaderboard participant: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *leaderboardRepository) TopReturns(ctx context.Context, since time.Time, minValueUSD float64, limit int) ([]*models.LeaderboardEntry, error) {
+	// Only wallets valued in both snapshots count, so adding a wallet mid-month doesn't
+	// read as a gain
+	query := `
+		WITH snaps AS (
+			SELECT v.user_id, v.wallet_id, v.value_usd, v.recorded_at
+			FROM wallet_valuations v
+			JOIN leaderboard_participants p ON p.user_id = v.user_id AND NOT p.hidden
+			JOIN wallets w ON w.id = v.wallet_id AND w.visibility <> 'private' AND w.removed_at IS NULL
+			WHERE v.recorded_at >= $1
+		), bounds AS (
+			SELECT user_id, MIN(recorded_at) AS first_at, MAX(recorded_at) AS last_at
+			FROM snaps
+			GROUP BY user_id
+			HAVING MAX(recorded_at) > MIN(recorded_at)
+		), first_snap AS (
+			SELECT s.user_id, s.wallet_id, s.value_usd
+			FROM snaps s JOIN bounds b ON b.user_id = s.user_id AND s.recorded_at = b.first_at
+		), last_snap AS (
+			SELECT s.wallet_id, s.value_usd
+			FROM snaps s JOIN bounds b ON b.user_id = s.user_id AND s.recorded_at = b.last_at
+		)
+		SELECT p.alias, ((SUM(l.value_usd) - SUM(f.value_usd)) / SUM(f.value_usd) * 100)::float8 AS return_pct
+		FROM first_snap f
+		JOIN last_snap l ON l.wallet_id = f.wallet_id
+		JOIN leaderboard_participants p ON p.user_id = f.user_id
+		GROUP BY p.user_id, p.alias
+		HAVING SUM(f.value_usd) >= $2
+		ORDER BY return_pct DESC, p.alias
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(ctx, query, since, minValueUSD, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returns leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.LeaderboardEntry{}
+	for rows.Next() {
+		entry := &models.LeaderboardEntry{Rank: len(entries) + 1}
+		if err := rows.Scan(&entry.Alias, &entry.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (r *leaderboardRepository) TopDiversified(ctx context.Context, minValueUSD float64, limit int) ([]*models.LeaderboardEntry, error) {
+	query := `
+		WITH holdings AS (
+			SELECT h.user_id, h.token_id, SUM(h.value_usd) AS value_usd
+			FROM (` + sharedHoldingsQuery + `) h
+			JOIN leaderboard_participants p ON p.user_id = h.user_id AND NOT p.hidden
+			GROUP BY h.user_id, h.token_id
+			HAVING SUM(h.value_usd) > 0
+		), totals AS (
+			SELECT user_id, SUM(value_usd) AS total, COUNT(*) AS tokens
+			FROM holdings
+			GROUP BY user_id
+			HAVING SUM(value_usd) >= $1
+		)
+		SELECT p.alias, (1 / SUM((h.value_usd / t.total) ^ 2))::float8 AS score, t.tokens
+		FROM holdings h
+		JOIN totals t ON t.user_id = h.user_id
+		JOIN leaderboard_participants p ON p.user_id = h.user_id
+		GROUP BY p.user_id, p.alias, t.tokens
+		ORDER BY score DESC, p.alias
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, minValueUSD, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diversification leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.LeaderboardEntry{}
+	for rows.Next() {
+		entry := &models.LeaderboardEntry{Rank: len(entries) + 1}
+		if err := rows.Scan(&entry.Alias, &entry.Score, &entry.Tokens); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (r *leaderboardRepository) GetPlatformStats(ctx context.Context, minHolders, tokenLimit int) (*models.PlatformStats, error) {
+	stats := &models.PlatformStats{TopTokens: []*models.PlatformTokenStat{}}
+
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(value_usd), 0)::float8, COUNT(DISTINCT wallet_id), COUNT(DISTINCT user_id)
+		FROM (`+sharedHoldingsQuery+`) h`,
+	).Scan(&stats.TotalValueUSD, &stats.Wallets, &stats.Users)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get platform totals: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT UPPER(symbol), COUNT(DISTINCT user_id), SUM(value_usd)::float8
+		FROM (`+sharedHoldingsQuery+`) h
+		GROUP BY UPPER(symbol)
+		HAVING COUNT(DISTINCT user_id) >= $1
+		ORDER BY COUNT(DISTINCT user_id) DESC, SUM(value_usd) DESC
+		LIMIT $2`, minHolders, tokenLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get most held tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token models.PlatformTokenStat
+		if err := rows.Scan(&token.Symbol, &token.Holders, &token.ValueUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan token stat: %w", err)
+		}
+		stats.TopTokens = append(stats.TopTokens, &token)
+	}
+	return stats, rows.Err()
+}
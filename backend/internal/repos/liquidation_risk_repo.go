@@ -0,0 +1,88 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LiquidationRiskRepository interface {
+	GetStates(ctx context.Context, alertIDs []uuid.UUID) (map[uuid.UUID]*models.LiquidationRiskState, error)
+	Upsert(ctx context.Context, state *models.LiquidationRiskState) error
+}
+
+type liquidationRiskRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLiquidationRiskRepository(db *pgxpool.Pool) LiquidationRiskRepository {
+	return &liquidationRiskRepository{db: db}
+}
+
+// GetStates returns the stored risk state of each alert that has one, keyed by alert ID
+func (r *liquidationRiskRepository) GetStates(ctx context.Context, alertIDs []uuid.UUID) (map[uuid.UUID]*models.LiquidationRiskState, error) {
+	states := make(map[uuid.UUID]*models.LiquidationRiskState)
+	if len(alertIDs) == 0 {
+		return states, nil
+	}
+
+	query := `
+		SELECT alert_id, level, health_factor::float8, notified_level, notified_at, checked_at
+		FROM liquidation_risk_state
+		WHERE alert_id = ANY($1)
+	`
+
+	rows, err := r.db.Query(ctx, query, alertIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get liquidation risk states: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var state models.LiquidationRiskState
+		err := rows.Scan(
+			&state.AlertID,
+			&state.Level,
+			&state.HealthFactor,
+			&state.NotifiedLevel,
+			&state.NotifiedAt,
+			&state.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan liquidation risk state: %w", err)
+		}
+		states[state.AlertID] = &state
+	}
+
+	return states, rows.Err()
+}
+
+func (r *liquidationRiskRepository) Upsert(ctx context.Context, state *models.LiquidationRiskState) error {
+	query := `
+		INSERT INTO liquidation_risk_state (alert_id, level, health_factor, notified_level, notified_at, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (alert_id) DO UPDATE SET
+			level = EXCLUDED.level,
+			health_factor = EXCLUDED.health_factor,
+			notified_level = EXCLUDED.notified_level,
+			notified_at = EXCLUDED.notified_at,
+			checked_at = EXCLUDED.checked_at
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		state.AlertID,
+		state.Level,
+		state.HealthFactor,
+		state.NotifiedLevel,
+		state.NotifiedAt,
+		state.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert liquidation risk state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MarketRepository reads the market data kept fresh by the price refresh job for views
+// that don't depend on a wallet
+type MarketRepository interface {
+	// GetTrendingCoins lists the trending coins, most searched first
+	GetTrendingCoins(ctx context.Context, limit int) ([]*models.TrendingCoin, error)
+	// GetGainers and GetLosers list the tokens held by at least minHolders users with the
+	// largest price rise or fall over the last day. Tokens on several chains count once.
+	GetGainers(ctx context.Context, minHolders, limit int) ([]*models.MarketMover, error)
+	GetLosers(ctx context.Context, minHolders, limit int) ([]*models.MarketMover, error)
+	// GetAPYChanges lists the pools with at least minTVLUSD whose APY moved most over the
+	// last day, either way
+	GetAPYChanges(ctx context.Context, minTVLUSD float64, limit int) ([]*models.APYChange, error)
+}
+
+type marketRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMarketRepository(db *pgxpool.Pool) MarketRepository {
+	return &marketRepository{db: db}
+}
+
+func (r *marketRepository) GetTrendingCoins(ctx context.Context, limit int) ([]*models.TrendingCoin, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT rank, coingecko_id, symbol, name, market_cap_rank, thumb_url,
+		       price_usd::float8, price_change_24h::float8
+		FROM trending_coins
+		ORDER BY rank
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trending coins: %w", err)
+	}
+	defer rows.Close()
+
+	coins := []*models.TrendingCoin{}
+	for rows.Next() {
+		var c models.TrendingCoin
+		if err := rows.Scan(&c.Rank, &c.CoinGeckoID, &c.Symbol, &c.Name, &c.MarketCapRank, &c.ThumbURL, &c.PriceUSD, &c.PriceChange24h); err != nil {
+			return nil, fmt.Errorf("failed to scan trending coin: %w", err)
+		}
+		coins = append(coins, &c)
+	}
+	return coins, rows.Err()
+}
+
+func (r *marketRepository) GetGainers(ctx context.Context, minHolders, limit int) ([]*models.MarketMover, error) {
+	return r.getMovers(ctx, `price_change_24h > 0 ORDER BY price_change_24h DESC`, minHolders, limit)
+}
+
+func (r *marketRepository) GetLosers(ctx context.Context, minHolders, limit int) ([]*models.MarketMover, error) {
+	return r.getMovers(ctx, `price_change_24h < 0 ORDER BY price_change_24h ASC`, minHolders, limit)
+}
+
+// getMovers lists held tokens priced in the last day, filtered and ordered by order. Each
+// symbol is represented by its most recently priced token. Private and testnet wallets
+// don't count as holders.
+func (r *marketRepository) getMovers(ctx context.Context, order string, minHolders, limit int) ([]*models.MarketMover, error) {
+	query := `
+		WITH held AS (
+			SELECT UPPER(t.symbol) AS symbol
+			FROM balances b
+			JOIN wallets w ON w.id = b.wallet_id
+			JOIN tokens t ON t.id = b.token_id
+			WHERE b.balance > 0 AND NOT w.is_testnet AND w.visibility <> 'private'
+			GROUP BY UPPER(t.symbol)
+			HAVING COUNT(DISTINCT w.user_id) >= $1
+		), priced AS (
+			SELECT DISTINCT ON (UPPER(t.symbol))
+			       UPPER(t.symbol) AS symbol, t.name, t.logo_uri,
+			       p.price_usd::float8 AS price_usd, p.price_change_24h::float8 AS price_change_24h
+			FROM tokens t
+			JOIN token_prices_latest p ON p.token_id = t.id
+			JOIN held h ON h.symbol = UPPER(t.symbol)
+			WHERE p.price_change_24h IS NOT NULL
+			  AND p.updated_at >= NOW() - INTERVAL '1 day'
+			ORDER BY UPPER(t.symbol), p.updated_at DESC
+		)
+		SELECT symbol, name, logo_uri, price_usd, price_change_24h
+		FROM priced
+		WHERE ` + order + `, symbol
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, minHolders, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get market movers: %w", err)
+	}
+	defer rows.Close()
+
+	movers := []*models.MarketMover{}
+	for rows.Next() {
+		var m models.MarketMover
+		if err := rows.Scan(&m.Symbol, &m.Name, &m.LogoURI, &m.PriceUSD, &m.PriceChange24h); err != nil {
+			return nil, fmt.Errorf("failed to scan market mover: %w", err)
+		}
+		movers = append(movers, &m)
+	}
+	return movers, rows.Err()
+}
+
+func (r *marketRepository) GetAPYChanges(ctx context.Context, minTVLUSD float64, limit int) ([]*models.APYChange, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT pool_id, protocol, symbol, chain, tvl_usd::float8, COALESCE(apy, 0)::float8,
+		       apy_pct_1d::float8, apy_pct_7d::float8
+		FROM yield_pools
+		WHERE is_active IS NOT FALSE
+		  AND apy_pct_1d IS NOT NULL AND apy_pct_1d <> 0
+		  AND tvl_usd >= $1
+		  AND updated_at >= NOW() - INTERVAL '1 day'
+		ORDER BY ABS(apy_pct_1d) DESC, pool_id
+		LIMIT $2`, minTVLUSD, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get APY changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []*models.APYChange{}
+	for rows.Next() {
+		var c models.APYChange
+		if err := rows.Scan(&c.PoolID, &c.Protocol, &c.Symbol, &c.Chain, &c.TVLUSD, &c.APY, &c.APYChange1d, &c.APYChange7d); err != nil {
+			return nil, fmt.Errorf("failed to scan APY change: %w", err)
+		}
+		changes = append(changes, &c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,91 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MetricHistoryRepository reads the price, APY and TVL snapshots the sync jobs record.
+// Every series is in [from, to), oldest first.
+type MetricHistoryRepository interface {
+	GetTokenPrices(ctx context.Context, address string, chainID int, from, to time.Time) ([]models.HistoryPoint, error)
+	GetPoolAPYs(ctx context.Context, poolID string, from, to time.Time) ([]models.HistoryPoint, error)
+	GetPoolTVLs(ctx context.Context, poolID string, from, to time.Time) ([]models.HistoryPoint, error)
+	GetProtocolTVLs(ctx context.Context, slug string, from, to time.Time) ([]models.HistoryPoint, error)
+}
+
+type metricHistoryRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMetricHistoryRepository(db *pgxpool.Pool) MetricHistoryRepository {
+	return &metricHistoryRepository{db: db}
+}
+
+func (r *metricHistoryRepository) GetTokenPrices(ctx context.Context, address string, chainID int, from, to time.Time) ([]models.HistoryPoint, error) {
+	query := `
+		SELECT h.timestamp, h.price_usd::float8
+		FROM price_history h
+		JOIN tokens t ON t.id = h.token_id
+		WHERE LOWER(t.address) = LOWER($1) AND t.chain_id = $2
+		  AND h.timestamp >= $3 AND h.timestamp < $4
+		ORDER BY h.timestamp ASC
+	`
+	return r.query(ctx, "token prices", query, address, chainID, from, to)
+}
+
+func (r *metricHistoryRepository) GetPoolAPYs(ctx context.Context, poolID string, from, to time.Time) ([]models.HistoryPoint, error) {
+	query := `
+		SELECT recorded_at, apy::float8
+		FROM yield_pool_history
+		WHERE pool_id = $1 AND apy IS NOT NULL
+		  AND recorded_at >= $2 AND recorded_at < $3
+		ORDER BY recorded_at ASC
+	`
+	return r.query(ctx, "pool APYs", query, poolID, from, to)
+}
+
+func (r *metricHistoryRepository) GetPoolTVLs(ctx context.Context, poolID string, from, to time.Time) ([]models.HistoryPoint, error) {
+	query := `
+		SELECT recorded_at, tvl_usd::float8
+		FROM yield_pool_history
+		WHERE pool_id = $1 AND tvl_usd IS NOT NULL
+		  AND recorded_at >= $2 AND recorded_at < $3
+		ORDER BY recorded_at ASC
+	`
+	return r.query(ctx, "pool TVLs", query, poolID, from, to)
+}
+
+func (r *metricHistoryRepository) GetProtocolTVLs(ctx context.Context, slug string, from, to time.Time) ([]models.HistoryPoint, error) {
+	query := `
+		SELECT h.recorded_at, h.tvl_usd::float8
+		FROM protocol_tvl_history h
+		JOIN protocols p ON p.id = h.protocol_id
+		WHERE p.slug = $1
+		  AND h.recorded_at >= $2 AND h.recorded_at < $3
+		ORDER BY h.recorded_at ASC
+	`
+	return r.query(ctx, "protocol TVLs", query, slug, from, to)
+}
+
+func (r *metricHistoryRepository) query(ctx context.Context, series, query string, args ...interface{}) ([]models.HistoryPoint, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", series, err)
+	}
+	defer rows.Close()
+
+	points := []models.HistoryPoint{}
+	for rows.Next() {
+		var point models.HistoryPoint
+		if err := rows.Scan(&point.Timestamp, &point.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", series, err)
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	addr "github.com/defi-dashboard/backend/pkg/address"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -29,12 +30,14 @@ func (r *nonceRepository) Store(ctx context.Context, address, nonce string, expi
 		INSERT INTO nonce_storage (address, nonce, expires_at)
 		VALUES ($1, $2, $3)
 	`
-	_, err := r.db.Exec(ctx, query, address, nonce, expiresAt)
+	_, err := r.db.Exec(ctx, query, addr.Normalize(address), nonce, expiresAt)
 	return err
 }
 
 // ValidateAndUse checks if nonce is valid and marks it as used
 func (r *nonceRepository) ValidateAndUse(ctx context.Context, address, nonce string) (bool, error) {
+	address = addr.Normalize(address)
+
 	// Start transaction
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -98,7 +101,7 @@ func (r *nonceRepository) GetByAddressAndNonce(ctx context.Context, address, non
 	`
 	
 	var ns models.NonceStorage
-	err := r.db.QueryRow(ctx, query, address, nonce).Scan(
+	err := r.db.QueryRow(ctx, query, addr.Normalize(address), nonce).Scan(
 		&ns.ID, &ns.Address, &ns.Nonce, &ns.ExpiresAt, &ns.Used, &ns.CreatedAt,
 	)
 	if err != nil {
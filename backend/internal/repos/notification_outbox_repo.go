@@ -0,0 +1,140 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NotificationOutboxRepository hands out outbox rows to dispatchers. A claim leases the
+// row until the lease runs out, so a dispatcher that dies mid-send only delays the row.
+type NotificationOutboxRepository interface {
+	Claim(ctx context.Context, id uuid.UUID, lease time.Duration) (*models.OutboxNotification, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxNotification, error)
+	MarkDispatched(ctx context.Context, id uuid.UUID, lastError *string) error
+	Release(ctx context.Context, id uuid.UUID, retryAt time.Time, lastError string) error
+	DeleteDispatchedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type notificationOutboxRepository struct {
+	db        *pgxpool.Pool
+	encryptor *crypto.Encryptor
+}
+
+// NewNotificationOutboxRepository opens the webhook URLs queued rows were sealed with,
+// as stored by the alert repository
+func NewNotificationOutboxRepository(db *pgxpool.Pool, encryptor *crypto.Encryptor) NotificationOutboxRepository {
+	return &notificationOutboxRepository{db: db, encryptor: encryptor}
+}
+
+// claimQuery leases up to $1 pending rows that no one holds, optionally only row $3.
+// SKIP LOCKED keeps concurrent dispatchers from claiming the same row.
+const claimQuery = `
+	WITH claimed AS (
+		UPDATE notification_outbox o
+		SET claimed_until = NOW() + make_interval(secs => $2), attempts = o.attempts + 1
+		WHERE o.id IN (
+			SELECT id FROM notification_outbox
+			WHERE dispatched_at IS NULL
+			  AND (claimed_until IS NULL OR claimed_until < NOW())
+			  AND ($3::uuid IS NULL OR id = $3)
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING o.id, o.alert_id, o.history_id, o.history_triggered_at, o.notification, o.urgent, o.attempts, o.last_error, o.created_at
+	)
+	SELECT c.id, c.alert_id, c.notification, c.urgent, c.attempts, c.last_error, c.created_at,
+		   h.id, h.alert_id, h.triggered_at, h.conditions_snapshot, h.triggered_value
+	FROM claimed c
+	JOIN alert_history h ON h.id = c.history_id AND h.triggered_at = c.history_triggered_at
+	ORDER BY c.created_at
+`
+
+// Claim leases one row, returning nil if it's dispatched or held by someone else
+func (r *notificationOutboxRepository) Claim(ctx context.Context, id uuid.UUID, lease time.Duration) (*models.OutboxNotification, error) {
+	claimed, err := r.claim(ctx, 1, lease, &id)
+	if err != nil || len(claimed) == 0 {
+		return nil, err
+	}
+	return &claimed[0], nil
+}
+
+// ClaimDue leases the oldest pending rows
+func (r *notificationOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxNotification, error) {
+	return r.claim(ctx, limit, lease, nil)
+}
+
+func (r *notificationOutboxRepository) claim(ctx context.Context, limit int, lease time.Duration, id *uuid.UUID) ([]models.OutboxNotification, error) {
+	rows, err := r.db.Query(ctx, claimQuery, limit, lease.Seconds(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []models.OutboxNotification
+	for rows.Next() {
+		var n models.OutboxNotification
+		var notificationJSON, conditionsJSON, triggeredValueJSON []byte
+		err := rows.Scan(
+			&n.ID, &n.AlertID, &notificationJSON, &n.Urgent, &n.Attempts, &n.LastError, &n.CreatedAt,
+			&n.History.ID, &n.History.AlertID, &n.History.TriggeredAt, &conditionsJSON, &triggeredValueJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox notification: %w", err)
+		}
+		if err := unmarshalNotification(r.encryptor, notificationJSON, &n.Notification); err != nil {
+			return nil, fmt.Errorf("failed to read outbox notification channels: %w", err)
+		}
+		if err := json.Unmarshal(conditionsJSON, &n.History.ConditionsSnapshot); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal conditions snapshot: %w", err)
+		}
+		if err := json.Unmarshal(triggeredValueJSON, &n.History.TriggeredValue); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal triggered value: %w", err)
+		}
+		claimed = append(claimed, n)
+	}
+
+	return claimed, rows.Err()
+}
+
+// MarkDispatched takes the row out of the pending set, recording why if it was given up on
+func (r *notificationOutboxRepository) MarkDispatched(ctx context.Context, id uuid.UUID, lastError *string) error {
+	query := `
+		UPDATE notification_outbox
+		SET dispatched_at = NOW(), claimed_until = NULL, last_error = COALESCE($2, last_error)
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, lastError); err != nil {
+		return fmt.Errorf("failed to mark outbox notification dispatched: %w", err)
+	}
+	return nil
+}
+
+// Release gives the row back for another attempt no earlier than retryAt
+func (r *notificationOutboxRepository) Release(ctx context.Context, id uuid.UUID, retryAt time.Time, lastError string) error {
+	query := `
+		UPDATE notification_outbox
+		SET claimed_until = $2, last_error = $3
+		WHERE id = $1
+	`
+	if _, err := r.db.Exec(ctx, query, id, retryAt, lastError); err != nil {
+		return fmt.Errorf("failed to release outbox notification: %w", err)
+	}
+	return nil
+}
+
+// DeleteDispatchedBefore prunes rows dispatched before the cutoff
+func (r *notificationOutboxRepository) DeleteDispatchedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM notification_outbox WHERE dispatched_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune notification outbox: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
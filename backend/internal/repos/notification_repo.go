@@ -0,0 +1,250 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationRepository interface {
+	GetSettings(ctx context.Context, userID uuid.UUID) (*models.NotificationSettings, error)
+	UpsertSettings(ctx context.Context, settings *models.NotificationSettings) error
+	Enqueue(ctx context.Context, notification *models.QueuedNotification) error
+	GetDue(ctx context.Context, limit int) ([]models.QueuedNotification, error)
+	DeleteQueued(ctx context.Context, id uuid.UUID) error
+	RescheduleQueued(ctx context.Context, id uuid.UUID, deliverAfter time.Time, lastError string) error
+	MarkHistoryNotified(ctx context.Context, historyID uuid.UUID, triggeredAt time.Time, sent bool, notificationError *string) error
+	RecordDelivery(ctx context.Context, delivery *models.AlertDelivery) error
+	HasSentDelivery(ctx context.Context, deliveryID uuid.UUID) (bool, error)
+}
+
+type notificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// GetSettings returns the user's notification settings, or the defaults if none are stored
+func (r *notificationRepository) GetSettings(ctx context.Context, userID uuid.UUID) (*models.NotificationSettings, error) {
+	query := `
+		SELECT user_id, quiet_hours_enabled, quiet_hours_start, quiet_hours_end,
+			   timezone, quiet_hours_action, updated_at
+		FROM user_notification_settings
+		WHERE user_id = $1
+	`
+
+	var settings models.NotificationSettings
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&settings.UserID,
+		&settings.QuietHoursEnabled,
+		&settings.QuietHoursStart,
+		&settings.QuietHoursEnd,
+		&settings.Timezone,
+		&settings.QuietHoursAction,
+		&settings.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return &models.NotificationSettings{
+			UserID:           userID,
+			QuietHoursStart:  "22:00",
+			QuietHoursEnd:    "07:00",
+			Timezone:         "UTC",
+			QuietHoursAction: models.QuietHoursActionQueue,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+func (r *notificationRepository) UpsertSettings(ctx context.Context, settings *models.NotificationSettings) error {
+	query := `
+		INSERT INTO user_notification_settings (
+			user_id, quiet_hours_enabled, quiet_hours_start, quiet_hours_end, timezone, quiet_hours_action
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			quiet_hours_enabled = EXCLUDED.quiet_hours_enabled,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			quiet_hours_action = EXCLUDED.quiet_hours_action
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		settings.UserID,
+		settings.QuietHoursEnabled,
+		settings.QuietHoursStart,
+		settings.QuietHoursEnd,
+		settings.Timezone,
+		settings.QuietHoursAction,
+	).Scan(&settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save notification settings: %w", err)
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) Enqueue(ctx context.Context, n *models.QueuedNotification) error {
+	payloadJSON, err := json.Marshal(n.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO notification_queue (
+			alert_id, history_id, history_triggered_at, user_id, channel, destination, payload, deliver_after
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		n.AlertID,
+		n.HistoryID,
+		n.Payload.TriggeredAt,
+		n.UserID,
+		n.Channel,
+		n.Destination,
+		payloadJSON,
+		n.DeliverAfter,
+	).Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+
+	return nil
+}
+
+// GetDue returns queued notifications whose delivery time has passed, oldest first
+func (r *notificationRepository) GetDue(ctx context.Context, limit int) ([]models.QueuedNotification, error) {
+	query := `
+		SELECT id, alert_id, history_id, user_id, channel, destination, payload,
+			   deliver_after, attempts, last_error, created_at
+		FROM notification_queue
+		WHERE deliver_after <= NOW()
+		ORDER BY deliver_after
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var queued []models.QueuedNotification
+	for rows.Next() {
+		var n models.QueuedNotification
+		var payloadJSON []byte
+		err := rows.Scan(
+			&n.ID,
+			&n.AlertID,
+			&n.HistoryID,
+			&n.UserID,
+			&n.Channel,
+			&n.Destination,
+			&payloadJSON,
+			&n.DeliverAfter,
+			&n.Attempts,
+			&n.LastError,
+			&n.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queued notification: %w", err)
+		}
+		if err := json.Unmarshal(payloadJSON, &n.Payload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal notification payload: %w", err)
+		}
+		queued = append(queued, n)
+	}
+
+	return queued, rows.Err()
+}
+
+func (r *notificationRepository) DeleteQueued(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM notification_queue WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete queued notification: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) RescheduleQueued(ctx context.Context, id uuid.UUID, deliverAfter time.Time, lastError string) error {
+	query := `
+		UPDATE notification_queue
+		SET deliver_after = $2, attempts = attempts + 1, last_error = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, id, deliverAfter, lastError); err != nil {
+		return fmt.Errorf("failed to reschedule queued notification: %w", err)
+	}
+	return nil
+}
+
+// MarkHistoryNotified records the notification outcome on a trigger. The trigger time
+// confines the update to the trigger's partition.
+func (r *notificationRepository) MarkHistoryNotified(ctx context.Context, historyID uuid.UUID, triggeredAt time.Time, sent bool, notificationError *string) error {
+	query := `
+		UPDATE alert_history
+		SET notification_sent = $3, notification_error = $4
+		WHERE id = $1 AND triggered_at = $2
+	`
+
+	if _, err := r.db.Exec(ctx, query, historyID, triggeredAt, sent, notificationError); err != nil {
+		return fmt.Errorf("failed to update alert history notification: %w", err)
+	}
+	return nil
+}
+
+// RecordDelivery appends a delivery outcome to the alert delivery log. A delivery is
+// evaluated when its trigger fired, so EvaluatedAt is also the trigger's time.
+func (r *notificationRepository) RecordDelivery(ctx context.Context, d *models.AlertDelivery) error {
+	query := `
+		INSERT INTO alert_deliveries (
+			alert_id, history_id, history_triggered_at, user_id, channel, status, error,
+			evaluated_at, delivered_at, latency_ms, delivery_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $3, $8, $9, $10)
+		RETURNING id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		d.AlertID,
+		d.HistoryID,
+		d.EvaluatedAt,
+		d.UserID,
+		d.Channel,
+		d.Status,
+		d.Error,
+		d.DeliveredAt,
+		d.LatencyMs,
+		d.DeliveryID,
+	).Scan(&d.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record alert delivery: %w", err)
+	}
+
+	return nil
+}
+
+// HasSentDelivery reports whether a delivery with this ID already went out
+func (r *notificationRepository) HasSentDelivery(ctx context.Context, deliveryID uuid.UUID) (bool, error) {
+	var sent bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM alert_deliveries WHERE delivery_id = $1 AND status = 'sent')`,
+		deliveryID).Scan(&sent)
+	if err != nil {
+		return false, fmt.Errorf("failed to check alert delivery: %w", err)
+	}
+	return sent, nil
+}
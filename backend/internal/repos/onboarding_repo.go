@@ -0,0 +1,105 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OnboardingRepository interface {
+	// GetSteps returns when the user completed each step they have completed
+	GetSteps(ctx context.Context, userID uuid.UUID) (map[string]time.Time, error)
+	// CompleteStep records the step, returning false if it already was
+	CompleteStep(ctx context.Context, userID uuid.UUID, step string) (bool, error)
+	// CompleteWalletStep is CompleteStep for the wallet's owner, whom it returns
+	CompleteWalletStep(ctx context.Context, walletID uuid.UUID, step string) (uuid.UUID, bool, error)
+	// Reconcile records the steps the user's data shows are done but weren't recorded,
+	// such as those done before onboarding was tracked, dated by the data
+	Reconcile(ctx context.Context, userID uuid.UUID) error
+}
+
+type onboardingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOnboardingRepository(db *pgxpool.Pool) OnboardingRepository {
+	return &onboardingRepository{db: db}
+}
+
+func (r *onboardingRepository) GetSteps(ctx context.Context, userID uuid.UUID) (map[string]time.Time, error) {
+	rows, err := r.db.Query(ctx, `SELECT step, completed_at FROM onboarding_steps WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get onboarding steps: %w", err)
+	}
+	defer rows.Close()
+
+	steps := make(map[string]time.Time)
+	for rows.Next() {
+		var step string
+		var completedAt time.Time
+		if err := rows.Scan(&step, &completedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan onboarding step: %w", err)
+		}
+		steps[step] = completedAt
+	}
+	return steps, rows.Err()
+}
+
+func (r *onboardingRepository) CompleteStep(ctx context.Context, userID uuid.UUID, step string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO onboarding_steps (user_id, step) VALUES ($1, $2)
+		ON CONFLICT (user_id, step) DO NOTHING`,
+		userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete onboarding step: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *onboardingRepository) CompleteWalletStep(ctx context.Context, walletID uuid.UUID, step string) (uuid.UUID, bool, error) {
+	var userID uuid.UUID
+	var inserted bool
+	err := r.db.QueryRow(ctx, `
+		WITH owner AS (
+			SELECT user_id FROM wallets WHERE id = $1
+		), inserted AS (
+			INSERT INTO onboarding_steps (user_id, step)
+			SELECT user_id, $2 FROM owner
+			ON CONFLICT (user_id, step) DO NOTHING
+			RETURNING user_id
+		)
+		SELECT o.user_id, EXISTS (SELECT 1 FROM inserted) FROM owner o`,
+		walletID, step).Scan(&userID, &inserted)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to complete onboarding step: %w", err)
+	}
+	return userID, inserted, nil
+}
+
+func (r *onboardingRepository) Reconcile(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO onboarding_steps (user_id, step, completed_at)
+		SELECT $1, step, completed_at FROM (
+			SELECT $2::text AS step, MIN(created_at) AS completed_at FROM wallets WHERE user_id = $1
+			UNION ALL
+			SELECT $3::text, MIN(b.completed_at) FROM wallet_backfills b
+			JOIN wallets w ON w.id = b.wallet_id
+			WHERE w.user_id = $1 AND b.status = $6
+			UNION ALL
+			SELECT $4::text, MIN(created_at) FROM alerts WHERE user_id = $1
+			UNION ALL
+			SELECT $5::text, updated_at FROM users WHERE id = $1 AND email IS NOT NULL
+		) done
+		WHERE completed_at IS NOT NULL
+		ON CONFLICT (user_id, step) DO NOTHING`,
+		userID, models.OnboardingStepWalletAdded, models.OnboardingStepFirstSync,
+		models.OnboardingStepFirstAlert, models.OnboardingStepEmailVerified, models.BackfillStatusCompleted)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile onboarding steps: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,205 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	// ErrPaperPortfolioNameTaken is returned when the user already has a paper portfolio
+	// with the name
+	ErrPaperPortfolioNameTaken = errors.New("paper portfolio name already taken")
+	// ErrPaperInsufficientCash is returned for a buy costing more than the portfolio's cash
+	ErrPaperInsufficientCash = errors.New("insufficient paper cash")
+	// ErrPaperInsufficientHoldings is returned for a sell of more than the portfolio holds
+	ErrPaperInsufficientHoldings = errors.New("insufficient paper holdings")
+)
+
+type PaperPortfolioRepository interface {
+	Create(ctx context.Context, portfolio *models.PaperPortfolio) error
+	// GetByID returns nil when there's no portfolio with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.PaperPortfolio, error)
+	// GetByUser lists the user's portfolios by name
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.PaperPortfolio, error)
+	// Delete reports whether the user had a portfolio with the ID
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	// GetTrades lists a portfolio's trades, oldest first
+	GetTrades(ctx context.Context, portfolioID uuid.UUID) ([]*models.PaperTrade, error)
+	// AddTrade records a trade and settles it against the portfolio's cash, returning the
+	// portfolio as it stands after. Buys need the cash and sells the holdings.
+	AddTrade(ctx context.Context, trade *models.PaperTrade) (*models.PaperPortfolio, error)
+}
+
+type paperPortfolioRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaperPortfolioRepository(db *pgxpool.Pool) PaperPortfolioRepository {
+	return &paperPortfolioRepository{db: db}
+}
+
+const paperPortfolioColumns = `id, user_id, name, starting_cash_usd, cash_usd, created_at, updated_at`
+
+func scanPaperPortfolio(row pgx.Row) (*models.PaperPortfolio, error) {
+	var p models.PaperPortfolio
+	err := row.Scan(
+		&p.ID,
+		&p.UserID,
+		&p.Name,
+		&p.StartingCashUSD,
+		&p.CashUSD,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *paperPortfolioRepository) Create(ctx context.Context, portfolio *models.PaperPortfolio) error {
+	query := `
+		INSERT INTO paper_portfolios (user_id, name, starting_cash_usd, cash_usd)
+		VALUES ($1, $2, $3, $3)
+		RETURNING ` + paperPortfolioColumns
+
+	created, err := scanPaperPortfolio(r.db.QueryRow(ctx, query, portfolio.UserID, portfolio.Name, portfolio.StartingCashUSD))
+	if isUniqueViolation(err, "paper_portfolios_user_id_name_key") {
+		return ErrPaperPortfolioNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create paper portfolio: %w", err)
+	}
+	*portfolio = *created
+	return nil
+}
+
+func (r *paperPortfolioRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.PaperPortfolio, error) {
+	query := `SELECT ` + paperPortfolioColumns + ` FROM paper_portfolios WHERE id = $1`
+
+	portfolio, err := scanPaperPortfolio(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper portfolio: %w", err)
+	}
+	return portfolio, nil
+}
+
+func (r *paperPortfolioRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.PaperPortfolio, error) {
+	query := `SELECT ` + paperPortfolioColumns + ` FROM paper_portfolios WHERE user_id = $1 ORDER BY name, id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper portfolios: %w", err)
+	}
+	defer rows.Close()
+
+	portfolios := []*models.PaperPortfolio{}
+	for rows.Next() {
+		portfolio, err := scanPaperPortfolio(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan paper portfolio: %w", err)
+		}
+		portfolios = append(portfolios, portfolio)
+	}
+	return portfolios, rows.Err()
+}
+
+func (r *paperPortfolioRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM paper_portfolios WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete paper portfolio: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *paperPortfolioRepository) GetTrades(ctx context.Context, portfolioID uuid.UUID) ([]*models.PaperTrade, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, portfolio_id, side, asset, quantity, price_usd, executed_at
+		FROM paper_trades
+		WHERE portfolio_id = $1
+		ORDER BY executed_at, id`, portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper trades: %w", err)
+	}
+	defer rows.Close()
+
+	trades := []*models.PaperTrade{}
+	for rows.Next() {
+		var t models.PaperTrade
+		if err := rows.Scan(&t.ID, &t.PortfolioID, &t.Side, &t.Asset, &t.Quantity, &t.PriceUSD, &t.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan paper trade: %w", err)
+		}
+		trades = append(trades, &t)
+	}
+	return trades, rows.Err()
+}
+
+func (r *paperPortfolioRepository) AddTrade(ctx context.Context, trade *models.PaperTrade) (*models.PaperPortfolio, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the portfolio so concurrent trades settle one after the other
+	var cash float64
+	if err := tx.QueryRow(ctx, `SELECT cash_usd FROM paper_portfolios WHERE id = $1 FOR UPDATE`, trade.PortfolioID).Scan(&cash); err != nil {
+		return nil, fmt.Errorf("failed to lock paper portfolio: %w", err)
+	}
+
+	var cashChange float64
+	switch trade.Side {
+	case models.PaperTradeBuy:
+		cashChange = -trade.Quantity * trade.PriceUSD
+		if cash+cashChange < 0 {
+			return nil, ErrPaperInsufficientCash
+		}
+	case models.PaperTradeSell:
+		var enough bool
+		err := tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(CASE WHEN side = $3 THEN -quantity ELSE quantity END), 0) >= $4::numeric
+			FROM paper_trades
+			WHERE portfolio_id = $1 AND asset = $2`,
+			trade.PortfolioID, trade.Asset, models.PaperTradeSell, trade.Quantity,
+		).Scan(&enough)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get paper holdings: %w", err)
+		}
+		if !enough {
+			return nil, ErrPaperInsufficientHoldings
+		}
+		cashChange = trade.Quantity * trade.PriceUSD
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO paper_trades (portfolio_id, side, asset, quantity, price_usd)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, executed_at`,
+		trade.PortfolioID, trade.Side, trade.Asset, trade.Quantity, trade.PriceUSD,
+	).Scan(&trade.ID, &trade.ExecutedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create paper trade: %w", err)
+	}
+
+	portfolio, err := scanPaperPortfolio(tx.QueryRow(ctx, `
+		UPDATE paper_portfolios SET cash_usd = GREATEST(cash_usd + $2, 0)
+		WHERE id = $1
+		RETURNING `+paperPortfolioColumns, trade.PortfolioID, cashChange))
+	if err != nil {
+		return nil, fmt.Errorf("failed to settle paper trade: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit paper trade: %w", err)
+	}
+	return portfolio, nil
+}
@@ -0,0 +1,44 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PartitionRepository manages the partitions of the tables partitioned by month
+type PartitionRepository interface {
+	// EnsureMonthlyPartitions creates the table's missing partitions for every month
+	// overlapping [from, to], returning how many were created
+	EnsureMonthlyPartitions(ctx context.Context, table string, from, to time.Time) (int, error)
+	// DropMonthlyPartitions detaches and drops the table's partitions for months ending
+	// at or before before, removing the rows referencing theirs, and returns how many
+	// were dropped
+	DropMonthlyPartitions(ctx context.Context, table string, before time.Time) (int, error)
+}
+
+type partitionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPartitionRepository(db *pgxpool.Pool) PartitionRepository {
+	return &partitionRepository{db: db}
+}
+
+func (r *partitionRepository) EnsureMonthlyPartitions(ctx context.Context, table string, from, to time.Time) (int, error) {
+	var created int
+	if err := r.db.QueryRow(ctx, `SELECT ensure_monthly_partitions($1, $2, $3)`, table, from, to).Scan(&created); err != nil {
+		return 0, fmt.Errorf("failed to create %s partitions: %w", table, err)
+	}
+	return created, nil
+}
+
+func (r *partitionRepository) DropMonthlyPartitions(ctx context.Context, table string, before time.Time) (int, error) {
+	var dropped int
+	if err := r.db.QueryRow(ctx, `SELECT drop_monthly_partitions($1, $2)`, table, before).Scan(&dropped); err != nil {
+		return 0, fmt.Errorf("failed to drop %s partitions: %w", table, err)
+	}
+	return dropped, nil
+}
@@ -0,0 +1,151 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PlanRepository interface {
+	// GetPlan returns the user's plan, the free plan for users who never had one set
+	GetPlan(ctx context.Context, userID uuid.UUID) (*models.UserPlan, error)
+	// SetPlan saves the plan, setting its UpdatedAt. It returns false if the user
+	// doesn't exist.
+	SetPlan(ctx context.Context, plan *models.UserPlan) (bool, error)
+	// ExtendTrial extends the user's pro trial by the given duration, from now if they
+	// aren't on one, returning when it ends. It returns nil if the user doesn't exist.
+	ExtendTrial(ctx context.Context, userID uuid.UUID, by time.Duration) (*time.Time, error)
+	// GetUsage returns how much of each plan-limited resource the user uses, counting
+	// exports made since exportsSince
+	GetUsage(ctx context.Context, userID uuid.UUID, exportsSince time.Time) (map[string]int, error)
+	// WatchesAddress reports whether one of the user's alerts already targets the address
+	WatchesAddress(ctx context.Context, userID uuid.UUID, address string) (bool, error)
+}
+
+type planRepository struct {
+	db txDB
+}
+
+func NewPlanRepository(db *pgxpool.Pool) PlanRepository {
+	return &planRepository{db: txDB{pool: db}}
+}
+
+func (r *planRepository) GetPlan(ctx context.Context, userID uuid.UUID) (*models.UserPlan, error) {
+	plan := &models.UserPlan{UserID: userID}
+	var overrides []byte
+	err := r.db.QueryRow(ctx, `
+		SELECT tier, limit_overrides, note, updated_by, updated_at, trial_ends_at
+		FROM user_plans WHERE user_id = $1`,
+		userID).Scan(&plan.Tier, &overrides, &plan.Note, &plan.UpdatedBy, &plan.UpdatedAt, &plan.TrialEndsAt)
+	if err == pgx.ErrNoRows {
+		plan.Tier = models.PlanTierFree
+		plan.LimitOverrides = map[string]int{}
+		return plan, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user plan: %w", err)
+	}
+	if err := json.Unmarshal(overrides, &plan.LimitOverrides); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal limit overrides: %w", err)
+	}
+	return plan, nil
+}
+
+func (r *planRepository) SetPlan(ctx context.Context, plan *models.UserPlan) (bool, error) {
+	overrides := plan.LimitOverrides
+	if overrides == nil {
+		overrides = map[string]int{}
+	}
+	overridesJSON, err := json.Marshal(overrides)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal limit overrides: %w", err)
+	}
+
+	var updatedAt time.Time
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO user_plans (user_id, tier, limit_overrides, note, updated_by, updated_at, trial_ends_at)
+		SELECT $1, $2, $3, $4, $5, NOW(), $6
+		WHERE EXISTS (SELECT 1 FROM users WHERE id = $1)
+		ON CONFLICT (user_id) DO UPDATE SET
+			tier = EXCLUDED.tier,
+			limit_overrides = EXCLUDED.limit_overrides,
+			note = EXCLUDED.note,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at,
+			trial_ends_at = EXCLUDED.trial_ends_at
+		RETURNING updated_at`,
+		plan.UserID, plan.Tier, overridesJSON, plan.Note, plan.UpdatedBy, plan.TrialEndsAt).Scan(&updatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set user plan: %w", err)
+	}
+	plan.UpdatedAt = &updatedAt
+	return true, nil
+}
+
+func (r *planRepository) ExtendTrial(ctx context.Context, userID uuid.UUID, by time.Duration) (*time.Time, error) {
+	var trialEndsAt time.Time
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO user_plans (user_id, trial_ends_at)
+		SELECT $1, NOW() + $2::interval
+		WHERE EXISTS (SELECT 1 FROM users WHERE id = $1)
+		ON CONFLICT (user_id) DO UPDATE SET
+			trial_ends_at = GREATEST(COALESCE(user_plans.trial_ends_at, NOW()), NOW()) + $2::interval
+		RETURNING trial_ends_at`,
+		userID, by).Scan(&trialEndsAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extend trial: %w", err)
+	}
+	return &trialEndsAt, nil
+}
+
+func (r *planRepository) GetUsage(ctx context.Context, userID uuid.UUID, exportsSince time.Time) (map[string]int, error) {
+	var wallets, alerts, watched, exports, webhooks int
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM wallets WHERE user_id = $1 AND removed_at IS NULL)
+				+ (SELECT COUNT(*) FROM bitcoin_accounts WHERE user_id = $1),
+			(SELECT COUNT(*) FROM alerts WHERE user_id = $1),
+			(SELECT COUNT(DISTINCT LOWER(target->>'identifier')) FROM alerts
+				WHERE user_id = $1 AND target->>'type' = 'address'),
+			(SELECT COUNT(*) FROM uploads WHERE user_id = $1 AND kind = $2 AND created_at >= $3),
+			(SELECT COUNT(*) FROM webhook_verifications WHERE user_id = $1)`,
+		userID, models.UploadKindExport, exportsSince,
+	).Scan(&wallets, &alerts, &watched, &exports, &webhooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return map[string]int{
+		models.QuotaWallets:          wallets,
+		models.QuotaAlerts:           alerts,
+		models.QuotaWatchedAddresses: watched,
+		models.QuotaExportsPerDay:    exports,
+		models.QuotaWebhookChannels:  webhooks,
+	}, nil
+}
+
+func (r *planRepository) WatchesAddress(ctx context.Context, userID uuid.UUID, address string) (bool, error) {
+	var watched bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM alerts
+			WHERE user_id = $1 AND target->>'type' = 'address' AND LOWER(target->>'identifier') = LOWER($2)
+		)`,
+		userID, address).Scan(&watched)
+	if err != nil {
+		return false, fmt.Errorf("failed to check watched address: %w", err)
+	}
+	return watched, nil
+}
\ No newline at end of file
@@ -37,7 +37,7 @@ func (r *protocolRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 		&protocol.RiskLevel, &protocol.CreatedAt, &protocol.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "protocol")
 	}
 
 	// Parse chains JSON
@@ -69,7 +69,7 @@ func (r *protocolRepository) GetBySlug(ctx context.Context, slug string) (*model
 		&protocol.RiskLevel, &protocol.CreatedAt, &protocol.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "protocol")
 	}
 
 	// Parse chains JSON
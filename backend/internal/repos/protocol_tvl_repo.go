@@ -0,0 +1,100 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ProtocolTVLRepository interface {
+	Record(ctx context.Context, snapshot *models.ProtocolTVLSnapshot) error
+	GetHistory(ctx context.Context, protocolID uuid.UUID, since time.Time) ([]*models.ProtocolTVLSnapshot, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+type protocolTVLRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProtocolTVLRepository(db *pgxpool.Pool) ProtocolTVLRepository {
+	return &protocolTVLRepository{db: db}
+}
+
+// Record stores a TVL snapshot and makes it the protocol's current total_tvl_usd
+func (r *protocolTVLRepository) Record(ctx context.Context, snapshot *models.ProtocolTVLSnapshot) error {
+	query := `
+		WITH snapshot AS (
+			INSERT INTO protocol_tvl_history (protocol_id, tvl_usd, chain_tvls)
+			VALUES ($1, $2, $3)
+			RETURNING protocol_id, tvl_usd, recorded_at
+		), updated AS (
+			UPDATE protocols p
+			SET total_tvl_usd = s.tvl_usd,
+			    updated_at = NOW()
+			FROM snapshot s
+			WHERE p.id = s.protocol_id
+		)
+		SELECT recorded_at FROM snapshot
+	`
+
+	chainTVLs := snapshot.ChainTVLs
+	if chainTVLs == nil {
+		chainTVLs = map[string]float64{}
+	}
+	chainsJSON, err := json.Marshal(chainTVLs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chain TVLs: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, query, snapshot.ProtocolID, snapshot.TVLUSD, chainsJSON).Scan(&snapshot.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record protocol TVL: %w", err)
+	}
+
+	return nil
+}
+
+// GetHistory returns a protocol's snapshots recorded since the given time, oldest first
+func (r *protocolTVLRepository) GetHistory(ctx context.Context, protocolID uuid.UUID, since time.Time) ([]*models.ProtocolTVLSnapshot, error) {
+	query := `
+		SELECT protocol_id, tvl_usd::float8, chain_tvls, recorded_at
+		FROM protocol_tvl_history
+		WHERE protocol_id = $1 AND recorded_at >= $2
+		ORDER BY recorded_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, protocolID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get protocol TVL history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*models.ProtocolTVLSnapshot
+	for rows.Next() {
+		var snapshot models.ProtocolTVLSnapshot
+		var chainsJSON []byte
+		if err := rows.Scan(&snapshot.ProtocolID, &snapshot.TVLUSD, &chainsJSON, &snapshot.RecordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan protocol TVL snapshot: %w", err)
+		}
+		if err := json.Unmarshal(chainsJSON, &snapshot.ChainTVLs); err != nil {
+			return nil, fmt.Errorf("failed to parse chain TVLs: %w", err)
+		}
+		history = append(history, &snapshot)
+	}
+
+	return history, rows.Err()
+}
+
+// DeleteOlderThan prunes snapshots recorded before the given time
+func (r *protocolTVLRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM protocol_tvl_history WHERE recorded_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune protocol TVL history: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,162 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ProviderCallRepository interface {
+	// InsertBatch logs the calls in one statement
+	InsertBatch(ctx context.Context, calls []models.ProviderCall) error
+	// List returns the calls matching the filter, newest first
+	List(ctx context.Context, filter models.ProviderCallFilter) ([]models.ProviderCall, error)
+	// DeleteBefore prunes calls started before the time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	// GetHealth counts each provider's calls and failures since the time
+	GetHealth(ctx context.Context, since time.Time) ([]models.ProviderHealth, error)
+}
+
+type providerCallRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProviderCallRepository(db *pgxpool.Pool) ProviderCallRepository {
+	return &providerCallRepository{db: db}
+}
+
+func (r *providerCallRepository) InsertBatch(ctx context.Context, calls []models.ProviderCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	requestIDs := make([]*string, len(calls))
+	providers := make([]string, len(calls))
+	methods := make([]string, len(calls))
+	hosts := make([]string, len(calls))
+	endpoints := make([]string, len(calls))
+	statuses := make([]*int, len(calls))
+	durations := make([]int, len(calls))
+	hashes := make([]*string, len(calls))
+	sizes := make([]*int64, len(calls))
+	errs := make([]*string, len(calls))
+	sources := make([]string, len(calls))
+	startedAt := make([]time.Time, len(calls))
+	for i, c := range calls {
+		requestIDs[i] = c.RequestID
+		providers[i] = c.Provider
+		methods[i] = c.Method
+		hosts[i] = c.Host
+		endpoints[i] = c.Endpoint
+		statuses[i] = c.Status
+		durations[i] = c.DurationMs
+		hashes[i] = c.ResponseHash
+		sizes[i] = c.ResponseBytes
+		errs[i] = c.Error
+		sources[i] = c.Source
+		startedAt[i] = c.StartedAt
+	}
+
+	query := `
+		INSERT INTO provider_calls (
+			request_id, provider, method, host, endpoint, status, duration_ms,
+			response_hash, response_bytes, error, source, started_at
+		)
+		SELECT * FROM UNNEST(
+			$1::varchar[], $2::varchar[], $3::varchar[], $4::varchar[], $5::text[], $6::int[], $7::int[],
+			$8::text[], $9::bigint[], $10::text[], $11::varchar[], $12::timestamptz[]
+		)
+	`
+
+	_, err := r.db.Exec(ctx, query, requestIDs, providers, methods, hosts, endpoints, statuses, durations,
+		hashes, sizes, errs, sources, startedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log provider calls: %w", err)
+	}
+	return nil
+}
+
+func (r *providerCallRepository) List(ctx context.Context, filter models.ProviderCallFilter) ([]models.ProviderCall, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.RequestID != "" {
+		add("request_id = $%d", filter.RequestID)
+	}
+	if filter.Provider != "" {
+		add("provider = $%d", filter.Provider)
+	}
+	if filter.From != nil {
+		add("started_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("started_at < $%d", *filter.To)
+	}
+
+	query := `
+		SELECT id, request_id, provider, method, host, endpoint, status, duration_ms,
+			   response_hash, response_bytes, error, source, started_at
+		FROM provider_calls
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY started_at DESC, id DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider calls: %w", err)
+	}
+	defer rows.Close()
+
+	calls := []models.ProviderCall{}
+	for rows.Next() {
+		var c models.ProviderCall
+		if err := rows.Scan(&c.ID, &c.RequestID, &c.Provider, &c.Method, &c.Host, &c.Endpoint, &c.Status,
+			&c.DurationMs, &c.ResponseHash, &c.ResponseBytes, &c.Error, &c.Source, &c.StartedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan provider call: %w", err)
+		}
+		calls = append(calls, c)
+	}
+	return calls, rows.Err()
+}
+
+func (r *providerCallRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM provider_calls WHERE started_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune provider calls: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *providerCallRepository) GetHealth(ctx context.Context, since time.Time) ([]models.ProviderHealth, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT provider, COUNT(*),
+			COUNT(*) FILTER (WHERE error IS NOT NULL OR status = 429 OR status >= 500)
+		FROM provider_calls
+		WHERE started_at >= $1
+		GROUP BY provider
+		ORDER BY provider`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider health: %w", err)
+	}
+	defer rows.Close()
+
+	var health []models.ProviderHealth
+	for rows.Next() {
+		var h models.ProviderHealth
+		if err := rows.Scan(&h.Provider, &h.Calls, &h.Failures); err != nil {
+			return nil, fmt.Errorf("failed to scan provider health: %w", err)
+		}
+		health = append(health, h)
+	}
+	return health, rows.Err()
+}
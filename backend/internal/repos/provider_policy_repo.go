@@ -0,0 +1,107 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ProviderPolicyRepository interface {
+	GetAll(ctx context.Context) ([]models.ProviderPolicy, error)
+	// Upsert replaces the policy for the same provider and chain, if there is one
+	Upsert(ctx context.Context, policy *models.ProviderPolicy) error
+	// Delete reports whether a policy with the ID existed
+	Delete(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type providerPolicyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewProviderPolicyRepository(db *pgxpool.Pool) ProviderPolicyRepository {
+	return &providerPolicyRepository{db: db}
+}
+
+const providerPolicyColumns = `id, provider, chain_id, disabled, weight::float8, max_slippage::float8,
+	max_fee_usd::float8, reason, updated_by, created_at, updated_at`
+
+func scanProviderPolicy(row pgx.Row) (models.ProviderPolicy, error) {
+	var p models.ProviderPolicy
+	err := row.Scan(
+		&p.ID,
+		&p.Provider,
+		&p.ChainID,
+		&p.Disabled,
+		&p.Weight,
+		&p.MaxSlippage,
+		&p.MaxFeeUSD,
+		&p.Reason,
+		&p.UpdatedBy,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	return p, err
+}
+
+func (r *providerPolicyRepository) GetAll(ctx context.Context) ([]models.ProviderPolicy, error) {
+	query := `SELECT ` + providerPolicyColumns + ` FROM provider_policies ORDER BY provider, chain_id NULLS FIRST`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []models.ProviderPolicy
+	for rows.Next() {
+		p, err := scanProviderPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan provider policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, rows.Err()
+}
+
+func (r *providerPolicyRepository) Upsert(ctx context.Context, policy *models.ProviderPolicy) error {
+	query := `
+		INSERT INTO provider_policies (provider, chain_id, disabled, weight, max_slippage, max_fee_usd, reason, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (provider, COALESCE(chain_id, 0)) DO UPDATE SET
+			disabled = EXCLUDED.disabled,
+			weight = EXCLUDED.weight,
+			max_slippage = EXCLUDED.max_slippage,
+			max_fee_usd = EXCLUDED.max_fee_usd,
+			reason = EXCLUDED.reason,
+			updated_by = EXCLUDED.updated_by
+		RETURNING ` + providerPolicyColumns
+
+	saved, err := scanProviderPolicy(r.db.QueryRow(ctx, query,
+		policy.Provider,
+		policy.ChainID,
+		policy.Disabled,
+		policy.Weight,
+		policy.MaxSlippage,
+		policy.MaxFeeUSD,
+		policy.Reason,
+		policy.UpdatedBy,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to save provider policy: %w", err)
+	}
+	*policy = saved
+	return nil
+}
+
+func (r *providerPolicyRepository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM provider_policies WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete provider policy: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
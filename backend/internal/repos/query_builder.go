@@ -0,0 +1,43 @@
+package repos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereBuilder assembles a WHERE clause from optional conditions, numbering their
+// placeholders in order, for searches with too many optional filters for a fixed query
+type whereBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition whose ? placeholders take args in order
+func (b *whereBuilder) add(condition string, args ...interface{}) {
+	var sb strings.Builder
+	next := 0
+	for _, c := range condition {
+		if c == '?' && next < len(args) {
+			b.args = append(b.args, args[next])
+			next++
+			fmt.Fprintf(&sb, "$%d", len(b.args))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	b.conditions = append(b.conditions, sb.String())
+}
+
+// arg adds a value used outside the conditions, such as a LIMIT, and returns its placeholder
+func (b *whereBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// clause returns the WHERE clause, or "" with no conditions
+func (b *whereBuilder) clause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, "\n\t\t  AND ")
+}
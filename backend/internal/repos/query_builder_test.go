@@ -0,0 +1,21 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhereBuilder(t *testing.T) {
+	var b whereBuilder
+	assert.Equal(t, "", b.clause())
+
+	b.add("yp.chain_id = ANY(?)", []int{1, 137})
+	b.add("yp.is_active")
+	b.add("yp.apy BETWEEN ? AND ?", 2.5, 10.0)
+	limit := b.arg(20)
+
+	assert.Equal(t, "WHERE yp.chain_id = ANY($1)\n\t\t  AND yp.is_active\n\t\t  AND yp.apy BETWEEN $2 AND $3", b.clause())
+	assert.Equal(t, "$4", limit)
+	assert.Equal(t, []interface{}{[]int{1, 137}, 2.5, 10.0, 20}, b.args)
+}
@@ -0,0 +1,177 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReferralRepository interface {
+	// GetCode returns the user's referral code, "" if they don't have one yet
+	GetCode(ctx context.Context, userID uuid.UUID) (string, error)
+	// CreateCode gives the user the code unless they already have one, returning the
+	// user's code. It returns "" if the code is taken by another user.
+	CreateCode(ctx context.Context, userID uuid.UUID, code string) (string, error)
+	// GetReferrer returns the user whose code it is, false if it's nobody's
+	GetReferrer(ctx context.Context, code string) (uuid.UUID, bool, error)
+	// GetByReferred returns the referral the user signed up with, nil if none
+	GetByReferred(ctx context.Context, referredID uuid.UUID) (*models.Referral, error)
+	// Create records a referral, setting its ID and CreatedAt. It returns false if the
+	// referred user already has one.
+	Create(ctx context.Context, referral *models.Referral) (bool, error)
+	// GetStats counts the referrer's referrals by status and sums the days they earned
+	GetStats(ctx context.Context, referrerID uuid.UUID) (*models.ReferralStats, error)
+	// ListByReferrer returns the referrer's latest referrals
+	ListByReferrer(ctx context.Context, referrerID uuid.UUID, limit int) ([]models.Referral, error)
+	// SharesWallet reports whether two users sign in with or track a common address
+	SharesWallet(ctx context.Context, userID, otherID uuid.UUID) (bool, error)
+}
+
+type referralRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReferralRepository(db *pgxpool.Pool) ReferralRepository {
+	return &referralRepository{db: db}
+}
+
+func (r *referralRepository) GetCode(ctx context.Context, userID uuid.UUID) (string, error) {
+	var code string
+	err := r.db.QueryRow(ctx, `SELECT code FROM referral_codes WHERE user_id = $1`, userID).Scan(&code)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get referral code: %w", err)
+	}
+	return code, nil
+}
+
+func (r *referralRepository) CreateCode(ctx context.Context, userID uuid.UUID, code string) (string, error) {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO referral_codes (user_id, code) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		userID, code)
+	if err != nil {
+		return "", fmt.Errorf("failed to create referral code: %w", err)
+	}
+	return r.GetCode(ctx, userID)
+}
+
+func (r *referralRepository) GetReferrer(ctx context.Context, code string) (uuid.UUID, bool, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT user_id FROM referral_codes WHERE code = $1`, code).Scan(&userID)
+	if err == pgx.ErrNoRows {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to get referrer: %w", err)
+	}
+	return userID, true, nil
+}
+
+const referralColumns = `id, referrer_id, referred_id, code, status, reject_reason,
+	referrer_reward_days, referred_reward_days, created_at`
+
+func scanReferral(row pgx.Row) (*models.Referral, error) {
+	var referral models.Referral
+	err := row.Scan(&referral.ID, &referral.ReferrerID, &referral.ReferredID, &referral.Code, &referral.Status,
+		&referral.RejectReason, &referral.ReferrerRewardDays, &referral.ReferredRewardDays, &referral.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+func (r *referralRepository) GetByReferred(ctx context.Context, referredID uuid.UUID) (*models.Referral, error) {
+	referral, err := scanReferral(r.db.QueryRow(ctx,
+		`SELECT `+referralColumns+` FROM referrals WHERE referred_id = $1`, referredID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral: %w", err)
+	}
+	return referral, nil
+}
+
+func (r *referralRepository) Create(ctx context.Context, referral *models.Referral) (bool, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO referrals (referrer_id, referred_id, code, status, reject_reason,
+			referrer_reward_days, referred_reward_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (referred_id) DO NOTHING
+		RETURNING id, created_at`,
+		referral.ReferrerID, referral.ReferredID, referral.Code, referral.Status, referral.RejectReason,
+		referral.ReferrerRewardDays, referral.ReferredRewardDays,
+	).Scan(&referral.ID, &referral.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create referral: %w", err)
+	}
+	return true, nil
+}
+
+func (r *referralRepository) GetStats(ctx context.Context, referrerID uuid.UUID) (*models.ReferralStats, error) {
+	stats := &models.ReferralStats{}
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'rewarded' AND referrer_reward_days > 0),
+			COUNT(*) FILTER (WHERE status = 'rejected'),
+			COALESCE(SUM(referrer_reward_days), 0)
+		FROM referrals WHERE referrer_id = $1`,
+		referrerID).Scan(&stats.Referred, &stats.Rewarded, &stats.Rejected, &stats.RewardDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (r *referralRepository) ListByReferrer(ctx context.Context, referrerID uuid.UUID, limit int) ([]models.Referral, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+referralColumns+` FROM referrals
+		WHERE referrer_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		referrerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrals: %w", err)
+	}
+	defer rows.Close()
+
+	referrals := []models.Referral{}
+	for rows.Next() {
+		referral, err := scanReferral(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan referral: %w", err)
+		}
+		referrals = append(referrals, *referral)
+	}
+	return referrals, rows.Err()
+}
+
+func (r *referralRepository) SharesWallet(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	var shared bool
+	err := r.db.QueryRow(ctx, `
+		WITH addresses AS (
+			SELECT id AS user_id, LOWER(address) AS address FROM users WHERE id IN ($1, $2)
+			UNION
+			SELECT user_id, LOWER(address) FROM wallets WHERE user_id IN ($1, $2)
+		)
+		SELECT EXISTS (
+			SELECT 1 FROM addresses a JOIN addresses b ON a.address = b.address
+			WHERE a.user_id = $1 AND b.user_id = $2
+		)`,
+		userID, otherID).Scan(&shared)
+	if err != nil {
+		return false, fmt.Errorf("failed to check shared wallets: %w", err)
+	}
+	return shared, nil
+}
\ No newline at end of file
@@ -0,0 +1,231 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReportRepository interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*models.ReportPreferences, error)
+	UpsertPreferences(ctx context.Context, prefs *models.ReportPreferences) error
+	GetUsersDueStatement(ctx context.Context, periodStart time.Time) ([]uuid.UUID, error)
+	GetStatements(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ReportStatement, error)
+	GetStatement(ctx context.Context, userID, statementID uuid.UUID) (*models.ReportStatement, error)
+	GetPreviousStatement(ctx context.Context, userID uuid.UUID, periodStart time.Time) (*models.ReportStatement, error)
+	UpsertStatement(ctx context.Context, statement *models.ReportStatement) (*uuid.UUID, error)
+	MarkStatementEmailed(ctx context.Context, statementID uuid.UUID, emailedAt time.Time) error
+}
+
+type reportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReportRepository(db *pgxpool.Pool) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// GetPreferences returns the user's report preferences, or the defaults if none are stored
+func (r *reportRepository) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.ReportPreferences, error) {
+	query := `
+		SELECT user_id, monthly_enabled, email_enabled, updated_at
+		FROM user_report_preferences
+		WHERE user_id = $1
+	`
+
+	var prefs models.ReportPreferences
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&prefs.UserID,
+		&prefs.MonthlyEnabled,
+		&prefs.EmailEnabled,
+		&prefs.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return &models.ReportPreferences{
+			UserID:       userID,
+			EmailEnabled: true,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report preferences: %w", err)
+	}
+
+	return &prefs, nil
+}
+
+func (r *reportRepository) UpsertPreferences(ctx context.Context, prefs *models.ReportPreferences) error {
+	query := `
+		INSERT INTO user_report_preferences (user_id, monthly_enabled, email_enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			monthly_enabled = EXCLUDED.monthly_enabled,
+			email_enabled = EXCLUDED.email_enabled
+		RETURNING updated_at
+	`
+
+	if err := r.db.QueryRow(ctx, query, prefs.UserID, prefs.MonthlyEnabled, prefs.EmailEnabled).Scan(&prefs.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to upsert report preferences: %w", err)
+	}
+	return nil
+}
+
+// GetUsersDueStatement returns the users who opted in to monthly statements and have no
+// statement yet for the period starting at periodStart
+func (r *reportRepository) GetUsersDueStatement(ctx context.Context, periodStart time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT p.user_id
+		FROM user_report_preferences p
+		WHERE p.monthly_enabled
+		  AND NOT EXISTS (
+			SELECT 1 FROM report_statements s
+			WHERE s.user_id = p.user_id AND s.period_start = $1
+		  )
+		ORDER BY p.user_id
+	`
+
+	rows, err := r.db.Query(ctx, query, periodStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly statement users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan monthly statement user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// reportStatementColumns selects from report_statements s joined to its file's uploads u
+const reportStatementColumns = `s.id, s.user_id, s.period_start, s.period_end, s.upload_id, u.size_bytes,
+		   s.total_value_usd::float8, s.emailed_at, s.created_at`
+
+const reportStatementTables = `report_statements s JOIN uploads u ON u.id = s.upload_id`
+
+func scanReportStatement(row pgx.Row) (*models.ReportStatement, error) {
+	var statement models.ReportStatement
+	err := row.Scan(
+		&statement.ID,
+		&statement.UserID,
+		&statement.PeriodStart,
+		&statement.PeriodEnd,
+		&statement.UploadID,
+		&statement.SizeBytes,
+		&statement.TotalValueUSD,
+		&statement.EmailedAt,
+		&statement.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// GetStatements returns the user's statements, newest period first
+func (r *reportRepository) GetStatements(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ReportStatement, error) {
+	query := `
+		SELECT ` + reportStatementColumns + `
+		FROM ` + reportStatementTables + `
+		WHERE s.user_id = $1
+		ORDER BY s.period_start DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report statements: %w", err)
+	}
+	defer rows.Close()
+
+	statements := []*models.ReportStatement{}
+	for rows.Next() {
+		statement, err := scanReportStatement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report statement: %w", err)
+		}
+		statements = append(statements, statement)
+	}
+
+	return statements, rows.Err()
+}
+
+// GetStatement returns one of the user's statements, or nil if there is none with that ID
+func (r *reportRepository) GetStatement(ctx context.Context, userID, statementID uuid.UUID) (*models.ReportStatement, error) {
+	query := `SELECT ` + reportStatementColumns + ` FROM ` + reportStatementTables + ` WHERE s.id = $1 AND s.user_id = $2`
+	return r.getStatement(ctx, query, statementID, userID)
+}
+
+// GetPreviousStatement returns the user's latest statement for a period before
+// periodStart, or nil if there is none
+func (r *reportRepository) GetPreviousStatement(ctx context.Context, userID uuid.UUID, periodStart time.Time) (*models.ReportStatement, error) {
+	query := `
+		SELECT ` + reportStatementColumns + `
+		FROM ` + reportStatementTables + `
+		WHERE s.user_id = $1 AND s.period_start < $2
+		ORDER BY s.period_start DESC
+		LIMIT 1
+	`
+	return r.getStatement(ctx, query, userID, periodStart)
+}
+
+func (r *reportRepository) getStatement(ctx context.Context, query string, args ...interface{}) (*models.ReportStatement, error) {
+	statement, err := scanReportStatement(r.db.QueryRow(ctx, query, args...))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report statement: %w", err)
+	}
+	return statement, nil
+}
+
+// UpsertStatement records a generated statement, replacing an earlier one for the same
+// period, and returns the upload the replaced statement pointed at, if any. Regenerating
+// resets the emailed time, as the new file hasn't been sent.
+func (r *reportRepository) UpsertStatement(ctx context.Context, statement *models.ReportStatement) (*uuid.UUID, error) {
+	query := `
+		WITH previous AS (
+			SELECT upload_id FROM report_statements WHERE user_id = $1 AND period_start = $2
+		)
+		INSERT INTO report_statements (user_id, period_start, period_end, upload_id, total_value_usd)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, period_start) DO UPDATE SET
+			period_end = EXCLUDED.period_end,
+			upload_id = EXCLUDED.upload_id,
+			total_value_usd = EXCLUDED.total_value_usd,
+			emailed_at = NULL,
+			created_at = NOW()
+		RETURNING id, created_at, (SELECT upload_id FROM previous)
+	`
+
+	var replaced *uuid.UUID
+	err := r.db.QueryRow(ctx, query,
+		statement.UserID,
+		statement.PeriodStart,
+		statement.PeriodEnd,
+		statement.UploadID,
+		statement.TotalValueUSD,
+	).Scan(&statement.ID, &statement.CreatedAt, &replaced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert report statement: %w", err)
+	}
+	statement.EmailedAt = nil
+	return replaced, nil
+}
+
+func (r *reportRepository) MarkStatementEmailed(ctx context.Context, statementID uuid.UUID, emailedAt time.Time) error {
+	if _, err := r.db.Exec(ctx, `UPDATE report_statements SET emailed_at = $2 WHERE id = $1`, statementID, emailedAt); err != nil {
+		return fmt.Errorf("failed to mark report statement emailed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RewardLockRepository interface {
+	// GetByUser lists the user's locks, soonest unlock first
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.RewardLock, error)
+	// GetByID returns nil when there's no lock with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.RewardLock, error)
+	Create(ctx context.Context, lock *models.RewardLock) error
+	// Delete reports whether the user had a lock with the ID
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	// ReplaceOnchain stores the on-chain locks just read for a wallet, forgetting ones no
+	// longer there, such as withdrawn escrows
+	ReplaceOnchain(ctx context.Context, userID uuid.UUID, walletAddress string, chainID int, locks []*models.RewardLock) error
+}
+
+type rewardLockRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRewardLockRepository(db *pgxpool.Pool) RewardLockRepository {
+	return &rewardLockRepository{db: db}
+}
+
+const rewardLockColumns = `id, user_id, wallet_address, chain_id, protocol, kind, source, contract,
+	token_address, token_symbol, amount::float8, released::float8, voting_power::float8,
+	starts_at, cliff_at, unlocks_at, synced_at, created_at, updated_at`
+
+func scanRewardLock(row pgx.Row) (*models.RewardLock, error) {
+	var l models.RewardLock
+	err := row.Scan(
+		&l.ID,
+		&l.UserID,
+		&l.WalletAddress,
+		&l.ChainID,
+		&l.Protocol,
+		&l.Kind,
+		&l.Source,
+		&l.Contract,
+		&l.TokenAddress,
+		&l.TokenSymbol,
+		&l.Amount,
+		&l.Released,
+		&l.VotingPower,
+		&l.StartsAt,
+		&l.CliffAt,
+		&l.UnlocksAt,
+		&l.SyncedAt,
+		&l.CreatedAt,
+		&l.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *rewardLockRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.RewardLock, error) {
+	query := `
+		SELECT ` + rewardLockColumns + `
+		FROM reward_locks
+		WHERE user_id = $1
+		ORDER BY unlocks_at, id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reward locks: %w", err)
+	}
+	defer rows.Close()
+
+	var locks []*models.RewardLock
+	for rows.Next() {
+		lock, err := scanRewardLock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reward lock: %w", err)
+		}
+		locks = append(locks, lock)
+	}
+
+	return locks, rows.Err()
+}
+
+func (r *rewardLockRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RewardLock, error) {
+	query := `SELECT ` + rewardLockColumns + ` FROM reward_locks WHERE id = $1`
+
+	lock, err := scanRewardLock(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reward lock: %w", err)
+	}
+	return lock, nil
+}
+
+func (r *rewardLockRepository) Create(ctx context.Context, lock *models.RewardLock) error {
+	query := `
+		INSERT INTO reward_locks (
+			user_id, wallet_address, chain_id, protocol, kind, source, contract,
+			token_address, token_symbol, amount, released, voting_power,
+			starts_at, cliff_at, unlocks_at, synced_at
+		) VALUES (
+			$1, LOWER($2), $3, $4, $5, $6, LOWER($7),
+			LOWER($8), $9, $10, $11, $12,
+			$13, $14, $15, $16
+		)
+		RETURNING ` + rewardLockColumns
+
+	created, err := scanRewardLock(r.db.QueryRow(ctx, query, rewardLockArgs(lock)...))
+	if err != nil {
+		return fmt.Errorf("failed to create reward lock: %w", err)
+	}
+	*lock = *created
+	return nil
+}
+
+func (r *rewardLockRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	query := `DELETE FROM reward_locks WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete reward lock: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *rewardLockRepository) ReplaceOnchain(ctx context.Context, userID uuid.UUID, walletAddress string, chainID int, locks []*models.RewardLock) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	contracts := make([]string, 0, len(locks))
+	for _, lock := range locks {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO reward_locks (
+				user_id, wallet_address, chain_id, protocol, kind, source, contract,
+				token_address, token_symbol, amount, released, voting_power,
+				starts_at, cliff_at, unlocks_at, synced_at
+			) VALUES (
+				$1, LOWER($2), $3, $4, $5, $6, LOWER($7),
+				LOWER($8), $9, $10, $11, $12,
+				$13, $14, $15, $16
+			)
+			ON CONFLICT (user_id, wallet_address, chain_id, contract) WHERE source = 'onchain' DO UPDATE SET
+				amount = EXCLUDED.amount,
+				voting_power = EXCLUDED.voting_power,
+				unlocks_at = EXCLUDED.unlocks_at,
+				synced_at = EXCLUDED.synced_at`,
+			rewardLockArgs(lock)...)
+		if err != nil {
+			return fmt.Errorf("failed to upsert reward lock: %w", err)
+		}
+		contracts = append(contracts, lock.Contract)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM reward_locks
+		WHERE user_id = $1 AND wallet_address = LOWER($2) AND chain_id = $3
+		  AND source = 'onchain' AND NOT (contract = ANY(SELECT LOWER(c) FROM unnest($4::text[]) AS c))`,
+		userID, walletAddress, chainID, contracts)
+	if err != nil {
+		return fmt.Errorf("failed to delete withdrawn reward locks: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func rewardLockArgs(lock *models.RewardLock) []interface{} {
+	return []interface{}{
+		lock.UserID,
+		lock.WalletAddress,
+		lock.ChainID,
+		lock.Protocol,
+		lock.Kind,
+		lock.Source,
+		lock.Contract,
+		lock.TokenAddress,
+		lock.TokenSymbol,
+		lock.Amount,
+		lock.Released,
+		lock.VotingPower,
+		lock.StartsAt,
+		lock.CliffAt,
+		lock.UnlocksAt,
+		lock.SyncedAt,
+	}
+}
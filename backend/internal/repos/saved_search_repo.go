@@ -0,0 +1,167 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrSavedSearchNameTaken is returned when the user already has a search of the same kind
+// with the name
+var ErrSavedSearchNameTaken = errors.New("saved search name already taken")
+
+type SavedSearchRepository interface {
+	Create(ctx context.Context, search *models.SavedSearch) error
+	// GetByID returns nil when there's no saved search with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SavedSearch, error)
+	// GetByShareToken returns nil when no saved search is shared with the token
+	GetByShareToken(ctx context.Context, token string) (*models.SavedSearch, error)
+	// GetByUser lists the user's saved searches by name, of one kind unless kind is empty
+	GetByUser(ctx context.Context, userID uuid.UUID, kind string) ([]*models.SavedSearch, error)
+	// Update saves the name, params and share token
+	Update(ctx context.Context, search *models.SavedSearch) error
+	// Delete reports whether the user had a saved search with the ID
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+}
+
+type savedSearchRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSavedSearchRepository(db *pgxpool.Pool) SavedSearchRepository {
+	return &savedSearchRepository{db: db}
+}
+
+const savedSearchColumns = `id, user_id, name, kind, params, share_token, created_at, updated_at`
+
+func scanSavedSearch(row pgx.Row) (*models.SavedSearch, error) {
+	var s models.SavedSearch
+	err := row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.Name,
+		&s.Kind,
+		&s.Params,
+		&s.ShareToken,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if s.Params == nil {
+		s.Params = map[string]string{}
+	}
+	return &s, nil
+}
+
+func (r *savedSearchRepository) Create(ctx context.Context, search *models.SavedSearch) error {
+	query := `
+		INSERT INTO saved_searches (user_id, name, kind, params, share_token)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + savedSearchColumns
+
+	saved, err := scanSavedSearch(r.db.QueryRow(ctx, query,
+		search.UserID,
+		search.Name,
+		search.Kind,
+		search.Params,
+		search.ShareToken,
+	))
+	if isUniqueViolation(err, "saved_searches_user_id_kind_name_key") {
+		return ErrSavedSearchNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+	*search = *saved
+	return nil
+}
+
+func (r *savedSearchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = $1`
+
+	search, err := scanSavedSearch(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return search, nil
+}
+
+func (r *savedSearchRepository) GetByShareToken(ctx context.Context, token string) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE share_token = $1`
+
+	search, err := scanSavedSearch(r.db.QueryRow(ctx, query, token))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared saved search: %w", err)
+	}
+	return search, nil
+}
+
+func (r *savedSearchRepository) GetByUser(ctx context.Context, userID uuid.UUID, kind string) ([]*models.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		WHERE user_id = $1 AND ($2 = '' OR kind = $2)
+		ORDER BY name, id`
+
+	rows, err := r.db.Query(ctx, query, userID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []*models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+func (r *savedSearchRepository) Update(ctx context.Context, search *models.SavedSearch) error {
+	query := `
+		UPDATE saved_searches
+		SET name = $3, params = $4, share_token = $5
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + savedSearchColumns
+
+	saved, err := scanSavedSearch(r.db.QueryRow(ctx, query,
+		search.ID,
+		search.UserID,
+		search.Name,
+		search.Params,
+		search.ShareToken,
+	))
+	if isUniqueViolation(err, "saved_searches_user_id_kind_name_key") {
+		return ErrSavedSearchNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved search: %w", err)
+	}
+	*search = *saved
+	return nil
+}
+
+func (r *savedSearchRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,259 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// errNoEncryptor is returned for sealed values read without ENCRYPTION_KEY set
+var errNoEncryptor = errors.New("value is encrypted but no encryption key is configured")
+
+// notificationSecrets lists the notification channels whose targets carry credentials:
+// webhook URLs usually embed a token
+func notificationSecrets(n *models.AlertNotification) []*string {
+	return []*string{&n.Webhook, &n.Discord}
+}
+
+// marshalNotification encodes an alert's channels for storage, sealing the webhook URLs
+// when encryptor is set
+func marshalNotification(encryptor *crypto.Encryptor, notification models.AlertNotification) ([]byte, error) {
+	if encryptor != nil {
+		for _, secret := range notificationSecrets(&notification) {
+			if *secret == "" || crypto.IsEncryptedString(*secret) {
+				continue
+			}
+			sealed, err := encryptor.EncryptString(*secret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt notification target: %w", err)
+			}
+			*secret = sealed
+		}
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	return data, nil
+}
+
+// unmarshalNotification decodes stored channels, opening sealed webhook URLs. URLs stored
+// before encryption was turned on are read as they are.
+func unmarshalNotification(encryptor *crypto.Encryptor, data []byte, notification *models.AlertNotification) error {
+	if err := json.Unmarshal(data, notification); err != nil {
+		return fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	for _, secret := range notificationSecrets(notification) {
+		if !crypto.IsEncryptedString(*secret) {
+			continue
+		}
+		if encryptor == nil {
+			return errNoEncryptor
+		}
+		plain, err := encryptor.DecryptString(*secret)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt notification target: %w", err)
+		}
+		*secret = plain
+	}
+	return nil
+}
+
+// SecretRepository re-seals the secrets stored across tables with the encryptor's
+// current key, for key rotation. Each method reports how many rows it changed.
+type SecretRepository interface {
+	RotateAPIKeys(ctx context.Context, encryptor *crypto.Encryptor) (int, error)
+	RotateExchangeCredentials(ctx context.Context, encryptor *crypto.Encryptor) (int, error)
+	// RotateAlertNotifications also seals webhook URLs stored in plaintext
+	RotateAlertNotifications(ctx context.Context, encryptor *crypto.Encryptor) (int, error)
+	// RotateOutboxNotifications re-seals the channels copied into queued and delivered
+	// notifications when their alerts triggered
+	RotateOutboxNotifications(ctx context.Context, encryptor *crypto.Encryptor) (int, error)
+	// RotateWebhookReports also seals webhook report URLs and secrets stored in plaintext
+	RotateWebhookReports(ctx context.Context, encryptor *crypto.Encryptor) (int, error)
+}
+
+type secretRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSecretRepository(db *pgxpool.Pool) SecretRepository {
+	return &secretRepository{db: db}
+}
+
+func (r *secretRepository) RotateAPIKeys(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	return r.rotateColumn(ctx, "user_api_keys", "encrypted_key", encryptor)
+}
+
+func (r *secretRepository) RotateExchangeCredentials(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	return r.rotateColumn(ctx, "exchange_accounts", "encrypted_credentials", encryptor)
+}
+
+// rotateColumn re-seals a BYTEA column of sealed values. Rows are locked while rotated
+// so a concurrent update isn't overwritten with the old value.
+func (r *secretRepository) rotateColumn(ctx context.Context, table, column string, encryptor *crypto.Encryptor) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT id, `+column+` FROM `+table+` FOR UPDATE`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s: %w", table, err)
+	}
+	rotated := make(map[uuid.UUID][]byte)
+	for rows.Next() {
+		var id uuid.UUID
+		var sealed []byte
+		if err := rows.Scan(&id, &sealed); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		resealed, changed, err := encryptor.Rotate(sealed)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to rotate %s %s: %w", table, id, err)
+		}
+		if changed {
+			rotated[id] = resealed
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get %s: %w", table, err)
+	}
+
+	for id, sealed := range rotated {
+		if _, err := tx.Exec(ctx, `UPDATE `+table+` SET `+column+` = $2 WHERE id = $1`, id, sealed); err != nil {
+			return 0, fmt.Errorf("failed to update %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit %s rotation: %w", table, err)
+	}
+	return len(rotated), nil
+}
+
+func (r *secretRepository) RotateAlertNotifications(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	return r.rotateNotifications(ctx, "alerts", "alert", encryptor)
+}
+
+func (r *secretRepository) RotateOutboxNotifications(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	return r.rotateNotifications(ctx, "notification_outbox", "outbox row", encryptor)
+}
+
+// rotateNotifications re-seals the webhook URLs in a table's notification column, the
+// channels of an alert as marshalNotification stores them
+func (r *secretRepository) rotateNotifications(ctx context.Context, table, what string, encryptor *crypto.Encryptor) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, notification FROM `+table+`
+		WHERE notification->>'webhook' <> '' OR notification->>'discord' <> ''
+		FOR UPDATE`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s notifications: %w", what, err)
+	}
+	rotated := make(map[uuid.UUID][]byte)
+	for rows.Next() {
+		var id uuid.UUID
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan %s notification: %w", what, err)
+		}
+		var notification models.AlertNotification
+		if err := json.Unmarshal(data, &notification); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to unmarshal notification of %s %s: %w", what, id, err)
+		}
+		changed := false
+		for _, secret := range notificationSecrets(&notification) {
+			resealed, rotatedSecret, err := encryptor.RotateString(*secret)
+			if err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to rotate notification of %s %s: %w", what, id, err)
+			}
+			*secret, changed = resealed, changed || rotatedSecret
+		}
+		if changed {
+			if rotated[id], err = json.Marshal(notification); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to marshal notification: %w", err)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get %s notifications: %w", what, err)
+	}
+
+	for id, data := range rotated {
+		if _, err := tx.Exec(ctx, `UPDATE `+table+` SET notification = $2 WHERE id = $1`, id, data); err != nil {
+			return 0, fmt.Errorf("failed to update %s notification: %w", what, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit %s notification rotation: %w", what, err)
+	}
+	return len(rotated), nil
+}
+
+func (r *secretRepository) RotateWebhookReports(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT id, url, secret FROM webhook_reports FOR UPDATE`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get webhook reports: %w", err)
+	}
+	rotated := make(map[uuid.UUID][2]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var secrets [2]string
+		if err := rows.Scan(&id, &secrets[0], &secrets[1]); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan webhook report: %w", err)
+		}
+		changed := false
+		for i := range secrets {
+			resealed, rotatedSecret, err := encryptor.RotateString(secrets[i])
+			if err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to rotate webhook report %s: %w", id, err)
+			}
+			secrets[i], changed = resealed, changed || rotatedSecret
+		}
+		if changed {
+			rotated[id] = secrets
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get webhook reports: %w", err)
+	}
+
+	for id, secrets := range rotated {
+		if _, err := tx.Exec(ctx, `UPDATE webhook_reports SET url = $2, secret = $3 WHERE id = $1`, id, secrets[0], secrets[1]); err != nil {
+			return 0, fmt.Errorf("failed to update webhook report: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit webhook report rotation: %w", err)
+	}
+	return len(rotated), nil
+}
@@ -0,0 +1,157 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StakingRepository interface {
+	GetLiquidStakingStates(ctx context.Context, address string, chainID int) (map[string]*models.LiquidStakingState, error)
+	UpsertLiquidStakingState(ctx context.Context, state *models.LiquidStakingState) error
+	DeleteLiquidStakingState(ctx context.Context, address string, chainID int, token string) error
+	GetValidators(ctx context.Context, userID uuid.UUID) ([]*models.StakingValidator, error)
+	AddValidator(ctx context.Context, validator *models.StakingValidator) error
+	DeleteValidator(ctx context.Context, userID uuid.UUID, validatorIndex int64) (bool, error)
+}
+
+type stakingRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewStakingRepository(db *pgxpool.Pool) StakingRepository {
+	return &stakingRepository{db: db}
+}
+
+// GetLiquidStakingStates returns the wallet's stored liquid staking principals, keyed by
+// lowercase token address
+func (r *stakingRepository) GetLiquidStakingStates(ctx context.Context, address string, chainID int) (map[string]*models.LiquidStakingState, error) {
+	query := `
+		SELECT wallet_address, chain_id, token, units::float8, principal_eth::float8, tracked_since
+		FROM liquid_staking_positions
+		WHERE wallet_address = LOWER($1) AND chain_id = $2
+	`
+
+	rows, err := r.db.Query(ctx, query, address, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get liquid staking states: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]*models.LiquidStakingState)
+	for rows.Next() {
+		var state models.LiquidStakingState
+		err := rows.Scan(
+			&state.WalletAddress,
+			&state.ChainID,
+			&state.Token,
+			&state.Units,
+			&state.PrincipalETH,
+			&state.TrackedSince,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan liquid staking state: %w", err)
+		}
+		states[state.Token] = &state
+	}
+
+	return states, rows.Err()
+}
+
+func (r *stakingRepository) UpsertLiquidStakingState(ctx context.Context, state *models.LiquidStakingState) error {
+	query := `
+		INSERT INTO liquid_staking_positions (wallet_address, chain_id, token, units, principal_eth)
+		VALUES (LOWER($1), $2, LOWER($3), $4, $5)
+		ON CONFLICT (wallet_address, chain_id, token) DO UPDATE SET
+			units = EXCLUDED.units,
+			principal_eth = EXCLUDED.principal_eth
+		RETURNING tracked_since
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		state.WalletAddress,
+		state.ChainID,
+		state.Token,
+		state.Units,
+		state.PrincipalETH,
+	).Scan(&state.TrackedSince)
+	if err != nil {
+		return fmt.Errorf("failed to upsert liquid staking state: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLiquidStakingState forgets a holding that was exited, so re-entering starts fresh
+func (r *stakingRepository) DeleteLiquidStakingState(ctx context.Context, address string, chainID int, token string) error {
+	query := `
+		DELETE FROM liquid_staking_positions
+		WHERE wallet_address = LOWER($1) AND chain_id = $2 AND token = LOWER($3)
+	`
+
+	if _, err := r.db.Exec(ctx, query, address, chainID, token); err != nil {
+		return fmt.Errorf("failed to delete liquid staking state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *stakingRepository) GetValidators(ctx context.Context, userID uuid.UUID) ([]*models.StakingValidator, error) {
+	query := `
+		SELECT id, user_id, validator_index, label, created_at
+		FROM staking_validators
+		WHERE user_id = $1
+		ORDER BY validator_index
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staking validators: %w", err)
+	}
+	defer rows.Close()
+
+	var validators []*models.StakingValidator
+	for rows.Next() {
+		var v models.StakingValidator
+		if err := rows.Scan(&v.ID, &v.UserID, &v.ValidatorIndex, &v.Label, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan staking validator: %w", err)
+		}
+		validators = append(validators, &v)
+	}
+
+	return validators, rows.Err()
+}
+
+// AddValidator tracks a validator for the user; adding one already tracked updates its label
+func (r *stakingRepository) AddValidator(ctx context.Context, validator *models.StakingValidator) error {
+	query := `
+		INSERT INTO staking_validators (user_id, validator_index, label)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, validator_index) DO UPDATE SET
+			label = EXCLUDED.label
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, validator.UserID, validator.ValidatorIndex, validator.Label).
+		Scan(&validator.ID, &validator.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add staking validator: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteValidator stops tracking a validator and reports whether the user tracked it
+func (r *stakingRepository) DeleteValidator(ctx context.Context, userID uuid.UUID, validatorIndex int64) (bool, error) {
+	query := `DELETE FROM staking_validators WHERE user_id = $1 AND validator_index = $2`
+
+	result, err := r.db.Exec(ctx, query, userID, validatorIndex)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete staking validator: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
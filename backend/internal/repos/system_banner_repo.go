@@ -10,12 +10,18 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrSystemBannerNotFound is returned when no system banner matches. It wraps ErrNotFound.
+var ErrSystemBannerNotFound = fmt.Errorf("system banner %w", ErrNotFound)
+
 type SystemBannerRepository interface {
 	GetAll(ctx context.Context, activeOnly bool) ([]models.SystemBanner, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*models.SystemBanner, error)
 	Create(ctx context.Context, banner *models.SystemBanner) error
 	Update(ctx context.Context, banner *models.SystemBanner) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// GetActiveForUser returns the active banners the user sees: those for everyone and
+	// those of broadcasts that reached them
+	GetActiveForUser(ctx context.Context, userID uuid.UUID) ([]models.SystemBanner, error)
 }
 
 type systemBannerRepository struct {
@@ -28,7 +34,7 @@ func NewSystemBannerRepository(db *pgxpool.Pool) SystemBannerRepository {
 
 func (r *systemBannerRepository) GetAll(ctx context.Context, activeOnly bool) ([]models.SystemBanner, error) {
 	query := `
-		SELECT id, title, message, level, active, created_at, updated_at
+		SELECT id, title, message, level, active, broadcast_id, created_at, updated_at
 		FROM system_banners
 	`
 	args := []interface{}{}
@@ -55,6 +61,7 @@ func (r *systemBannerRepository) GetAll(ctx context.Context, activeOnly bool) ([
 			&banner.Message,
 			&banner.Level,
 			&banner.Active,
+			&banner.BroadcastID,
 			&banner.CreatedAt,
 			&banner.UpdatedAt,
 		)
@@ -69,7 +76,7 @@ func (r *systemBannerRepository) GetAll(ctx context.Context, activeOnly bool) ([
 
 func (r *systemBannerRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SystemBanner, error) {
 	query := `
-		SELECT id, title, message, level, active, created_at, updated_at
+		SELECT id, title, message, level, active, broadcast_id, created_at, updated_at
 		FROM system_banners
 		WHERE id = $1
 	`
@@ -81,12 +88,13 @@ func (r *systemBannerRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 		&banner.Message,
 		&banner.Level,
 		&banner.Active,
+		&banner.BroadcastID,
 		&banner.CreatedAt,
 		&banner.UpdatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("system banner not found")
+			return nil, ErrSystemBannerNotFound
 		}
 		return nil, fmt.Errorf("failed to get system banner: %w", err)
 	}
@@ -133,7 +141,7 @@ func (r *systemBannerRepository) Update(ctx context.Context, banner *models.Syst
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return fmt.Errorf("system banner not found")
+			return ErrSystemBannerNotFound
 		}
 		return fmt.Errorf("failed to update system banner: %w", err)
 	}
@@ -151,8 +159,38 @@ func (r *systemBannerRepository) Delete(ctx context.Context, id uuid.UUID) error
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("system banner not found")
+		return ErrSystemBannerNotFound
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *systemBannerRepository) GetActiveForUser(ctx context.Context, userID uuid.UUID) ([]models.SystemBanner, error) {
+	query := `
+		SELECT b.id, b.title, b.message, b.level, b.active, b.broadcast_id, b.created_at, b.updated_at
+		FROM system_banners b
+		WHERE b.active
+		  AND (b.broadcast_id IS NULL
+		       OR EXISTS (SELECT 1 FROM broadcast_recipients r
+		                  WHERE r.broadcast_id = b.broadcast_id AND r.user_id = $1))
+		ORDER BY b.created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user's system banners: %w", err)
+	}
+	defer rows.Close()
+
+	banners := []models.SystemBanner{}
+	for rows.Next() {
+		var banner models.SystemBanner
+		if err := rows.Scan(&banner.ID, &banner.Title, &banner.Message, &banner.Level, &banner.Active,
+			&banner.BroadcastID, &banner.CreatedAt, &banner.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan system banner: %w", err)
+		}
+		banners = append(banners, banner)
+	}
+
+	return banners, rows.Err()
+}
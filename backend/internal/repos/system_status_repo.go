@@ -0,0 +1,108 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Data sources whose freshness GetDataFreshness reports
+const (
+	DataSourcePrices   = "prices"
+	DataSourcePools    = "pools"
+	DataSourceBalances = "balances"
+)
+
+type SystemStatusRepository interface {
+	// RecordJobRun overwrites the job's latest run. A nil runErr is a success; degraded,
+	// if not empty, is why the run left work undone.
+	RecordJobRun(ctx context.Context, jobName string, startedAt, finishedAt time.Time, runErr error, degraded string) error
+	// GetJobRuns returns the latest run of every job that has run, by job name
+	GetJobRuns(ctx context.Context) (map[string]*models.JobRun, error)
+	// GetDataFreshness returns when each data source was last written, leaving out
+	// empty ones
+	GetDataFreshness(ctx context.Context) (map[string]time.Time, error)
+}
+
+type systemStatusRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSystemStatusRepository(db *pgxpool.Pool) SystemStatusRepository {
+	return &systemStatusRepository{db: db}
+}
+
+func (r *systemStatusRepository) RecordJobRun(ctx context.Context, jobName string, startedAt, finishedAt time.Time, runErr error, degraded string) error {
+	var lastError, lastDegraded *string
+	var lastSuccess *time.Time
+	if runErr != nil {
+		message := runErr.Error()
+		lastError = &message
+	} else {
+		lastSuccess = &finishedAt
+	}
+	if degraded != "" {
+		lastDegraded = &degraded
+	}
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO job_runs (job_name, last_started_at, last_finished_at, last_success_at, last_error, last_duration_ms, last_degraded)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (job_name) DO UPDATE SET
+			last_started_at = EXCLUDED.last_started_at,
+			last_finished_at = EXCLUDED.last_finished_at,
+			last_success_at = COALESCE(EXCLUDED.last_success_at, job_runs.last_success_at),
+			last_error = EXCLUDED.last_error,
+			last_duration_ms = EXCLUDED.last_duration_ms,
+			last_degraded = EXCLUDED.last_degraded`,
+		jobName, startedAt, finishedAt, lastSuccess, lastError, int(finishedAt.Sub(startedAt).Milliseconds()), lastDegraded)
+	if err != nil {
+		return fmt.Errorf("failed to record job run: %w", err)
+	}
+	return nil
+}
+
+func (r *systemStatusRepository) GetJobRuns(ctx context.Context) (map[string]*models.JobRun, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT job_name, last_started_at, last_finished_at, last_success_at, last_error, last_duration_ms, last_degraded
+		FROM job_runs`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make(map[string]*models.JobRun)
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(&run.JobName, &run.LastStartedAt, &run.LastFinishedAt, &run.LastSuccessAt,
+			&run.LastError, &run.LastDurationMs, &run.LastDegraded); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		runs[run.JobName] = &run
+	}
+	return runs, rows.Err()
+}
+
+func (r *systemStatusRepository) GetDataFreshness(ctx context.Context) (map[string]time.Time, error) {
+	var prices, pools, balances *time.Time
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			(SELECT MAX(updated_at) FROM token_prices_latest),
+			(SELECT MAX(updated_at) FROM yield_pools),
+			(SELECT MAX(recorded_at) FROM wallet_valuations)`,
+	).Scan(&prices, &pools, &balances)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data freshness: %w", err)
+	}
+
+	freshness := make(map[string]time.Time)
+	for source, at := range map[string]*time.Time{DataSourcePrices: prices, DataSourcePools: pools, DataSourceBalances: balances} {
+		if at != nil {
+			freshness[source] = *at
+		}
+	}
+	return freshness, nil
+}
\ No newline at end of file
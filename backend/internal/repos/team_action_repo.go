@@ -0,0 +1,172 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTeamActionPending = errors.New("the change is already awaiting approval")
+
+type TeamActionRepository interface {
+	// Create queues the action, first expiring the team's lapsed ones. It returns
+	// ErrTeamActionPending when the same change is already awaiting approval, and
+	// updates action in place.
+	Create(ctx context.Context, action *models.TeamPendingAction) error
+	// GetByID returns nil when there's no such action
+	GetByID(ctx context.Context, id uuid.UUID) (*models.TeamPendingAction, error)
+	// GetByTeam returns the team's latest actions, newest first
+	GetByTeam(ctx context.Context, teamID uuid.UUID, limit int) ([]*models.TeamPendingAction, error)
+	// Approve marks a pending, unexpired action approved by someone other than its
+	// requester, returning nil when it can't be
+	Approve(ctx context.Context, teamID, id, approverID uuid.UUID) (*models.TeamPendingAction, error)
+	// Reject reports whether the action was pending and unexpired
+	Reject(ctx context.Context, teamID, id, userID uuid.UUID) (bool, error)
+	// MarkFailed records why an approved action couldn't be applied
+	MarkFailed(ctx context.Context, id uuid.UUID, message string) error
+}
+
+type teamActionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTeamActionRepository(db *pgxpool.Pool) TeamActionRepository {
+	return &teamActionRepository{db: db}
+}
+
+// Lapsed actions read as expired before the next Create sweeps them
+const teamActionColumns = `id, team_id, action, target_id, payload, requested_by,
+	CASE WHEN status = 'pending' AND expires_at <= NOW() THEN 'expired' ELSE status END,
+	decided_by, decided_at, error, expires_at, created_at`
+
+func scanTeamAction(row pgx.Row) (*models.TeamPendingAction, error) {
+	var a models.TeamPendingAction
+	var payload []byte
+	if err := row.Scan(&a.ID, &a.TeamID, &a.Action, &a.TargetID, &payload, &a.RequestedBy, &a.Status,
+		&a.DecidedBy, &a.DecidedAt, &a.Error, &a.ExpiresAt, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		a.Payload = payload
+	}
+	return &a, nil
+}
+
+func (r *teamActionRepository) Create(ctx context.Context, action *models.TeamPendingAction) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// An expired request mustn't block asking again
+	if _, err := tx.Exec(ctx, `
+		UPDATE team_pending_actions SET status = 'expired'
+		WHERE team_id = $1 AND status = 'pending' AND expires_at <= NOW()`, action.TeamID); err != nil {
+		return fmt.Errorf("failed to expire team actions: %w", err)
+	}
+
+	var payload []byte
+	if len(action.Payload) > 0 {
+		payload = action.Payload
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO team_pending_actions (team_id, action, target_id, payload, requested_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at`,
+		action.TeamID, action.Action, action.TargetID, payload, action.RequestedBy, action.ExpiresAt,
+	).Scan(&action.ID, &action.Status, &action.CreatedAt)
+	if isUniqueViolation(err, "idx_team_pending_actions_open") {
+		return ErrTeamActionPending
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create team action: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit team action: %w", err)
+	}
+	return nil
+}
+
+func (r *teamActionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TeamPendingAction, error) {
+	query := `SELECT ` + teamActionColumns + ` FROM team_pending_actions WHERE id = $1`
+
+	action, err := scanTeamAction(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team action: %w", err)
+	}
+	return action, nil
+}
+
+func (r *teamActionRepository) GetByTeam(ctx context.Context, teamID uuid.UUID, limit int) ([]*models.TeamPendingAction, error) {
+	query := `
+		SELECT ` + teamActionColumns + `
+		FROM team_pending_actions
+		WHERE team_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, teamID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []*models.TeamPendingAction{}
+	for rows.Next() {
+		action, err := scanTeamAction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan team action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
+
+func (r *teamActionRepository) Approve(ctx context.Context, teamID, id, approverID uuid.UUID) (*models.TeamPendingAction, error) {
+	query := `
+		UPDATE team_pending_actions
+		SET status = 'approved', decided_by = $3, decided_at = NOW()
+		WHERE id = $1 AND team_id = $2 AND status = 'pending' AND expires_at > NOW()
+		  AND requested_by <> $3
+		RETURNING ` + teamActionColumns
+
+	action, err := scanTeamAction(r.db.QueryRow(ctx, query, id, teamID, approverID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve team action: %w", err)
+	}
+	return action, nil
+}
+
+func (r *teamActionRepository) Reject(ctx context.Context, teamID, id, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE team_pending_actions
+		SET status = 'rejected', decided_by = $3, decided_at = NOW()
+		WHERE id = $1 AND team_id = $2 AND status = 'pending' AND expires_at > NOW()`,
+		id, teamID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to reject team action: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *teamActionRepository) MarkFailed(ctx context.Context, id uuid.UUID, message string) error {
+	_, err := r.db.Exec(ctx, `UPDATE team_pending_actions SET status = 'failed', error = $2 WHERE id = $1`, id, message)
+	if err != nil {
+		return fmt.Errorf("failed to mark team action failed: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,333 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTeamMemberExists = errors.New("user is already a team member")
+
+type TeamRepository interface {
+	// Create stores the team with its creator as owner. It updates team in place.
+	Create(ctx context.Context, team *models.Team) error
+	// GetByID returns nil when there's no such team
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error)
+	// GetByUser returns the teams the user is a member of, with their role in each
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.Team, error)
+	// GetRole returns the user's role on the team, or "" when they aren't a member
+	GetRole(ctx context.Context, teamID, userID uuid.UUID) (string, error)
+	GetMembers(ctx context.Context, teamID uuid.UUID) ([]*models.TeamMember, error)
+	// AddMember returns ErrTeamMemberExists when the user already is one. It updates
+	// member in place.
+	AddMember(ctx context.Context, member *models.TeamMember) error
+	// RemoveMember reports whether the user was a member
+	RemoveMember(ctx context.Context, teamID, userID uuid.UUID) (bool, error)
+
+	GetPolicies(ctx context.Context, teamID uuid.UUID) ([]*models.EscalationPolicy, error)
+	// GetPolicy returns nil when there's no such policy
+	GetPolicy(ctx context.Context, id uuid.UUID) (*models.EscalationPolicy, error)
+	// CreatePolicy and UpdatePolicy update policy in place
+	CreatePolicy(ctx context.Context, policy *models.EscalationPolicy) error
+	UpdatePolicy(ctx context.Context, policy *models.EscalationPolicy) error
+	// DeletePolicy reports whether the team had the policy
+	DeletePolicy(ctx context.Context, teamID, id uuid.UUID) (bool, error)
+
+	// GetShifts returns the team's shifts overlapping [from, to), earliest first
+	GetShifts(ctx context.Context, teamID uuid.UUID, from, to time.Time) ([]*models.OnCallShift, error)
+	// CreateShift updates shift in place
+	CreateShift(ctx context.Context, shift *models.OnCallShift) error
+	// DeleteShift reports whether the team had the shift
+	DeleteShift(ctx context.Context, teamID, id uuid.UUID) (bool, error)
+}
+
+type teamRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTeamRepository(db *pgxpool.Pool) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+const teamColumns = `t.id, t.name, t.created_by, t.created_at, t.updated_at`
+
+func (r *teamRepository) Create(ctx context.Context, team *models.Team) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO teams (name, created_by) VALUES ($1, $2) RETURNING id, created_at, updated_at`
+	if err := tx.QueryRow(ctx, query, team.Name, team.CreatedBy).Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)`,
+		team.ID, team.CreatedBy, models.TeamRoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to add team owner: %w", err)
+	}
+	team.Role = models.TeamRoleOwner
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit team: %w", err)
+	}
+	return nil
+}
+
+func (r *teamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error) {
+	query := `SELECT ` + teamColumns + ` FROM teams t WHERE t.id = $1`
+
+	var t models.Team
+	err := r.db.QueryRow(ctx, query, id).Scan(&t.ID, &t.Name, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *teamRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.Team, error) {
+	query := `
+		SELECT ` + teamColumns + `, m.role
+		FROM teams t
+		JOIN team_members m ON m.team_id = t.id
+		WHERE m.user_id = $1
+		ORDER BY t.name
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := []*models.Team{}
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt, &t.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, &t)
+	}
+	return teams, rows.Err()
+}
+
+func (r *teamRepository) GetRole(ctx context.Context, teamID, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.QueryRow(ctx, `SELECT role FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get team role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *teamRepository) GetMembers(ctx context.Context, teamID uuid.UUID) ([]*models.TeamMember, error) {
+	query := `
+		SELECT m.team_id, m.user_id, u.address, m.role, m.created_at
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY m.created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*models.TeamMember{}
+	for rows.Next() {
+		var m models.TeamMember
+		if err := rows.Scan(&m.TeamID, &m.UserID, &m.Address, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team member: %w", err)
+		}
+		members = append(members, &m)
+	}
+	return members, rows.Err()
+}
+
+func (r *teamRepository) AddMember(ctx context.Context, member *models.TeamMember) error {
+	query := `INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3) RETURNING created_at`
+
+	err := r.db.QueryRow(ctx, query, member.TeamID, member.UserID, member.Role).Scan(&member.CreatedAt)
+	if isUniqueViolation(err, "team_members_pkey") {
+		return ErrTeamMemberExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add team member: %w", err)
+	}
+	return nil
+}
+
+func (r *teamRepository) RemoveMember(ctx context.Context, teamID, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove team member: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+const escalationPolicyColumns = `id, team_id, name, steps, created_at, updated_at`
+
+func scanEscalationPolicy(row pgx.Row) (*models.EscalationPolicy, error) {
+	var p models.EscalationPolicy
+	var stepsJSON []byte
+	if err := row.Scan(&p.ID, &p.TeamID, &p.Name, &stepsJSON, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stepsJSON, &p.Steps); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal escalation steps: %w", err)
+	}
+	return &p, nil
+}
+
+func (r *teamRepository) GetPolicies(ctx context.Context, teamID uuid.UUID) ([]*models.EscalationPolicy, error) {
+	query := `SELECT ` + escalationPolicyColumns + ` FROM escalation_policies WHERE team_id = $1 ORDER BY name`
+
+	rows, err := r.db.Query(ctx, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []*models.EscalationPolicy{}
+	for rows.Next() {
+		policy, err := scanEscalationPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+func (r *teamRepository) GetPolicy(ctx context.Context, id uuid.UUID) (*models.EscalationPolicy, error) {
+	query := `SELECT ` + escalationPolicyColumns + ` FROM escalation_policies WHERE id = $1`
+
+	policy, err := scanEscalationPolicy(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (r *teamRepository) CreatePolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	stepsJSON, err := json.Marshal(policy.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal escalation steps: %w", err)
+	}
+
+	query := `
+		INSERT INTO escalation_policies (team_id, name, steps)
+		VALUES ($1, $2, $3)
+		RETURNING ` + escalationPolicyColumns
+
+	saved, err := scanEscalationPolicy(r.db.QueryRow(ctx, query, policy.TeamID, policy.Name, stepsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to create escalation policy: %w", err)
+	}
+	*policy = *saved
+	return nil
+}
+
+func (r *teamRepository) UpdatePolicy(ctx context.Context, policy *models.EscalationPolicy) error {
+	stepsJSON, err := json.Marshal(policy.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal escalation steps: %w", err)
+	}
+
+	query := `
+		UPDATE escalation_policies SET name = $3, steps = $4
+		WHERE id = $1 AND team_id = $2
+		RETURNING ` + escalationPolicyColumns
+
+	saved, err := scanEscalationPolicy(r.db.QueryRow(ctx, query, policy.ID, policy.TeamID, policy.Name, stepsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to update escalation policy: %w", err)
+	}
+	*policy = *saved
+	return nil
+}
+
+func (r *teamRepository) DeletePolicy(ctx context.Context, teamID, id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM escalation_policies WHERE id = $1 AND team_id = $2`, id, teamID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete escalation policy: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+const onCallShiftColumns = `id, team_id, user_id, starts_at, ends_at, created_at`
+
+func scanOnCallShift(row pgx.Row) (*models.OnCallShift, error) {
+	var s models.OnCallShift
+	if err := row.Scan(&s.ID, &s.TeamID, &s.UserID, &s.StartsAt, &s.EndsAt, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *teamRepository) GetShifts(ctx context.Context, teamID uuid.UUID, from, to time.Time) ([]*models.OnCallShift, error) {
+	query := `
+		SELECT ` + onCallShiftColumns + `
+		FROM on_call_shifts
+		WHERE team_id = $1 AND starts_at < $3 AND ends_at > $2
+		ORDER BY starts_at, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, teamID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-call shifts: %w", err)
+	}
+	defer rows.Close()
+
+	shifts := []*models.OnCallShift{}
+	for rows.Next() {
+		shift, err := scanOnCallShift(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan on-call shift: %w", err)
+		}
+		shifts = append(shifts, shift)
+	}
+	return shifts, rows.Err()
+}
+
+func (r *teamRepository) CreateShift(ctx context.Context, shift *models.OnCallShift) error {
+	query := `
+		INSERT INTO on_call_shifts (team_id, user_id, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + onCallShiftColumns
+
+	saved, err := scanOnCallShift(r.db.QueryRow(ctx, query, shift.TeamID, shift.UserID, shift.StartsAt, shift.EndsAt))
+	if err != nil {
+		return fmt.Errorf("failed to create on-call shift: %w", err)
+	}
+	*shift = *saved
+	return nil
+}
+
+func (r *teamRepository) DeleteShift(ctx context.Context, teamID, id uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM on_call_shifts WHERE id = $1 AND team_id = $2`, id, teamID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete on-call shift: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,145 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TokenHolderRepository stores how concentrated held tokens' supplies are among their
+// largest holders
+type TokenHolderRepository interface {
+	// GetStaleTokens lists ERC-20 tokens some wallet holds whose holders were never
+	// fetched or were fetched longer ago than olderThan, never-fetched and smallest
+	// market caps first
+	GetStaleTokens(ctx context.Context, olderThan time.Duration, limit int) ([]*models.Token, error)
+	Upsert(ctx context.Context, stats *models.TokenHolderStats) error
+	// Get returns a token's holder stats, or nil when they weren't fetched or the
+	// explorer has none
+	Get(ctx context.Context, tokenID uuid.UUID) (*models.TokenHolderStats, error)
+	// GetUserBalance returns the raw amount of a token a user's wallets hold together
+	GetUserBalance(ctx context.Context, userID, tokenID uuid.UUID) (string, error)
+}
+
+type tokenHolderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokenHolderRepository(db *pgxpool.Pool) TokenHolderRepository {
+	return &tokenHolderRepository{db: db}
+}
+
+func (r *tokenHolderRepository) GetStaleTokens(ctx context.Context, olderThan time.Duration, limit int) ([]*models.Token, error) {
+	query := `
+		SELECT t.id, t.address, t.chain_id, t.symbol, t.name, t.decimals, t.market_cap::float8
+		FROM tokens t
+		LEFT JOIN token_holder_stats s ON s.token_id = t.id
+		WHERE t.chain_id > 0
+		  AND t.address ~* '^0x[0-9a-f]{40}$'
+		  AND t.address <> '0x0000000000000000000000000000000000000000'
+		  AND (s.token_id IS NULL OR s.fetched_at < $1)
+		  AND EXISTS (
+		      SELECT 1 FROM balances b JOIN wallets w ON w.id = b.wallet_id AND w.removed_at IS NULL
+		      WHERE b.token_id = t.id AND b.balance > 0)
+		ORDER BY s.fetched_at ASC NULLS FIRST, t.market_cap ASC NULLS FIRST
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, time.Now().Add(-olderThan), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale held tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.Token
+	for rows.Next() {
+		var token models.Token
+		if err := rows.Scan(&token.ID, &token.Address, &token.ChainID, &token.Symbol, &token.Name,
+			&token.Decimals, &token.MarketCap); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *tokenHolderRepository) Upsert(ctx context.Context, stats *models.TokenHolderStats) error {
+	query := `
+		INSERT INTO token_holder_stats (
+			token_id, total_supply, top_holders, top10_share_pct, source, not_found, fetched_at
+		) VALUES ($1, $2::numeric, $3, $4, $5, $6, NOW())
+		ON CONFLICT (token_id) DO UPDATE SET
+			total_supply = EXCLUDED.total_supply,
+			top_holders = EXCLUDED.top_holders,
+			top10_share_pct = EXCLUDED.top10_share_pct,
+			source = EXCLUDED.source,
+			not_found = EXCLUDED.not_found,
+			fetched_at = EXCLUDED.fetched_at
+		RETURNING fetched_at
+	`
+
+	holders := stats.TopHolders
+	if holders == nil {
+		holders = []models.TokenHolderShare{}
+	}
+	holdersJSON, _ := json.Marshal(holders)
+
+	var totalSupply, top10 interface{}
+	if !stats.NotFound {
+		totalSupply, top10 = stats.TotalSupply, stats.Top10SharePct
+	}
+
+	err := r.db.QueryRow(ctx, query,
+		stats.TokenID, totalSupply, holdersJSON, top10, stats.Source, stats.NotFound,
+	).Scan(&stats.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert token holder stats: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenHolderRepository) Get(ctx context.Context, tokenID uuid.UUID) (*models.TokenHolderStats, error) {
+	query := `
+		SELECT token_id, total_supply::text, top_holders, top10_share_pct::float8, source, fetched_at
+		FROM token_holder_stats
+		WHERE token_id = $1 AND NOT not_found
+	`
+
+	var stats models.TokenHolderStats
+	var holdersJSON []byte
+	err := r.db.QueryRow(ctx, query, tokenID).Scan(
+		&stats.TokenID, &stats.TotalSupply, &holdersJSON, &stats.Top10SharePct, &stats.Source, &stats.FetchedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token holder stats: %w", err)
+	}
+	if err := json.Unmarshal(holdersJSON, &stats.TopHolders); err != nil {
+		return nil, fmt.Errorf("failed to decode top holders: %w", err)
+	}
+	return &stats, nil
+}
+
+func (r *tokenHolderRepository) GetUserBalance(ctx context.Context, userID, tokenID uuid.UUID) (string, error) {
+	query := `
+		SELECT COALESCE(SUM(b.balance), 0)::text
+		FROM balances b
+		JOIN wallets w ON w.id = b.wallet_id AND w.removed_at IS NULL
+		WHERE w.user_id = $1 AND b.token_id = $2
+	`
+
+	var balance string
+	if err := r.db.QueryRow(ctx, query, userID, tokenID).Scan(&balance); err != nil {
+		return "", fmt.Errorf("failed to get user token balance: %w", err)
+	}
+	return balance, nil
+}
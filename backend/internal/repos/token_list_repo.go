@@ -0,0 +1,100 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tokenListBatchSize is how many listed tokens one statement syncs
+const tokenListBatchSize = 1000
+
+type TokenListRepository interface {
+	// SyncTokens stores tokens from token lists, keyed by address and chain. Tokens the
+	// table lacks are added. Of those it has, only gaps are filled: a row without a
+	// symbol takes the list's symbol, name and decimals, and one without a name or logo
+	// takes those; metadata already read from a provider or on-chain is kept. Rows
+	// stored under a differently cased address count as the same token.
+	SyncTokens(ctx context.Context, tokens []*models.Token) (*models.TokenListSyncResult, error)
+}
+
+type tokenListRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokenListRepository(db *pgxpool.Pool) TokenListRepository {
+	return &tokenListRepository{db: db}
+}
+
+func (r *tokenListRepository) SyncTokens(ctx context.Context, tokens []*models.Token) (*models.TokenListSyncResult, error) {
+	query := `
+		INSERT INTO tokens AS t (address, chain_id, symbol, name, decimals, logo_uri)
+		SELECT l.address, l.chain_id, LEFT(l.symbol, 50), LEFT(l.name, 255), l.decimals, NULLIF(l.logo_uri, '')
+		FROM unnest($1::text[], $2::int[], $3::text[], $4::text[], $5::int[], $6::text[])
+		     AS l(address, chain_id, symbol, name, decimals, logo_uri)
+		WHERE NOT EXISTS (
+		    SELECT 1 FROM tokens o
+		    WHERE o.chain_id = l.chain_id AND lower(o.address) = l.address AND o.address <> l.address
+		)
+		ON CONFLICT (address, chain_id) DO UPDATE SET
+		    symbol = CASE WHEN t.symbol = '' THEN EXCLUDED.symbol ELSE t.symbol END,
+		    decimals = CASE WHEN t.symbol = '' THEN EXCLUDED.decimals ELSE t.decimals END,
+		    name = CASE WHEN t.name = '' OR t.symbol = '' THEN EXCLUDED.name ELSE t.name END,
+		    logo_uri = COALESCE(t.logo_uri, EXCLUDED.logo_uri)
+		WHERE t.symbol = '' OR t.name = '' OR (t.logo_uri IS NULL AND EXCLUDED.logo_uri IS NOT NULL)
+		RETURNING t.chain_id, t.address, (xmax = 0) AS inserted`
+
+	result := &models.TokenListSyncResult{Changed: make(map[int][]string)}
+	for start := 0; start < len(tokens); start += tokenListBatchSize {
+		end := start + tokenListBatchSize
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		batch := tokens[start:end]
+
+		addresses := make([]string, len(batch))
+		chainIDs := make([]int, len(batch))
+		symbols := make([]string, len(batch))
+		names := make([]string, len(batch))
+		decimals := make([]int, len(batch))
+		logos := make([]string, len(batch))
+		for i, token := range batch {
+			addresses[i] = strings.ToLower(token.Address)
+			chainIDs[i] = token.ChainID
+			symbols[i] = token.Symbol
+			names[i] = token.Name
+			decimals[i] = token.Decimals
+			if token.LogoURI != nil {
+				logos[i] = *token.LogoURI
+			}
+		}
+
+		rows, err := r.db.Query(ctx, query, addresses, chainIDs, symbols, names, decimals, logos)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync listed tokens: %w", err)
+		}
+		for rows.Next() {
+			var chainID int
+			var address string
+			var inserted bool
+			if err := rows.Scan(&chainID, &address, &inserted); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan synced token: %w", err)
+			}
+			if inserted {
+				result.Inserted++
+			} else {
+				result.Filled++
+			}
+			result.Changed[chainID] = append(result.Changed[chainID], address)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to sync listed tokens: %w", err)
+		}
+	}
+	return result, nil
+}
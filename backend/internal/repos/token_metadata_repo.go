@@ -0,0 +1,272 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTokenNotFound is returned when no token matches. It wraps ErrNotFound.
+var ErrTokenNotFound = fmt.Errorf("token %w", ErrNotFound)
+
+type TokenMetadataRepository interface {
+	GetToken(ctx context.Context, tokenID uuid.UUID) (*models.Token, error)
+	GetByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.TokenMetadata, error)
+	GetStaleTokens(ctx context.Context, olderThan time.Duration, limit int) ([]*models.Token, error)
+	Upsert(ctx context.Context, metadata *models.TokenMetadata) error
+	BoostPriceRefresh(ctx context.Context, tokenID uuid.UUID, until time.Time) error
+	SavePrice(ctx context.Context, tokenID uuid.UUID, priceUSD, priceChange24h float64) (time.Time, error)
+}
+
+type tokenMetadataRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokenMetadataRepository(db *pgxpool.Pool) TokenMetadataRepository {
+	return &tokenMetadataRepository{db: db}
+}
+
+const tokenMetadataColumns = `m.token_id, m.coingecko_id, m.categories, m.description, m.website,
+			   m.twitter, m.telegram, m.discord, m.github, m.image_url,
+			   m.circulating_supply::float8, m.not_found, m.fetched_at`
+
+// GetToken returns a token with its metadata attached when it has been enriched
+func (r *tokenMetadataRepository) GetToken(ctx context.Context, tokenID uuid.UUID) (*models.Token, error) {
+	query := `
+		SELECT t.id, t.address, t.chain_id, t.symbol, t.name, t.decimals, t.logo_uri,
+			   p.price_usd::float8, p.price_change_24h::float8, t.market_cap::float8, t.total_supply::text,
+			   p.updated_at, t.created_at, t.updated_at,
+			   m.token_id IS NOT NULL AND NOT m.not_found, ` + tokenMetadataColumns + `
+		FROM tokens t
+		LEFT JOIN token_prices_latest p ON p.token_id = t.id
+		LEFT JOIN token_metadata m ON m.token_id = t.id
+		WHERE t.id = $1
+	`
+
+	var token models.Token
+	var hasMetadata bool
+	var metadataTokenID *uuid.UUID
+	var categories []string
+	var notFound *bool
+	var fetchedAt *time.Time
+	metadata := &models.TokenMetadata{}
+
+	err := r.db.QueryRow(ctx, query, tokenID).Scan(
+		&token.ID,
+		&token.Address,
+		&token.ChainID,
+		&token.Symbol,
+		&token.Name,
+		&token.Decimals,
+		&token.LogoURI,
+		&token.PriceUSD,
+		&token.PriceChange24h,
+		&token.MarketCap,
+		&token.TotalSupply,
+		&token.LastUpdated,
+		&token.CreatedAt,
+		&token.UpdatedAt,
+		&hasMetadata,
+		&metadataTokenID,
+		&metadata.CoinGeckoID,
+		&categories,
+		&metadata.Description,
+		&metadata.Website,
+		&metadata.Twitter,
+		&metadata.Telegram,
+		&metadata.Discord,
+		&metadata.GitHub,
+		&metadata.ImageURL,
+		&metadata.CirculatingSupply,
+		&notFound,
+		&fetchedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if hasMetadata {
+		metadata.TokenID = *metadataTokenID
+		metadata.Categories = categories
+		metadata.FetchedAt = *fetchedAt
+		token.Metadata = metadata
+	}
+
+	return &token, nil
+}
+
+// GetByAddresses returns enriched metadata for tokens on a chain, keyed by lowercase address
+func (r *tokenMetadataRepository) GetByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.TokenMetadata, error) {
+	result := make(map[string]*models.TokenMetadata)
+	if len(addresses) == 0 {
+		return result, nil
+	}
+
+	lowered := make([]string, len(addresses))
+	for i, a := range addresses {
+		lowered[i] = strings.ToLower(a)
+	}
+
+	query := `
+		SELECT lower(t.address), ` + tokenMetadataColumns + `
+		FROM token_metadata m
+		JOIN tokens t ON t.id = m.token_id
+		WHERE t.chain_id = $1 AND lower(t.address) = ANY($2) AND NOT m.not_found
+	`
+
+	rows, err := r.db.Query(ctx, query, chainID, lowered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token metadata: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var m models.TokenMetadata
+		err := rows.Scan(
+			&address,
+			&m.TokenID,
+			&m.CoinGeckoID,
+			&m.Categories,
+			&m.Description,
+			&m.Website,
+			&m.Twitter,
+			&m.Telegram,
+			&m.Discord,
+			&m.GitHub,
+			&m.ImageURL,
+			&m.CirculatingSupply,
+			&m.NotFound,
+			&m.FetchedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token metadata: %w", err)
+		}
+		result[address] = &m
+	}
+
+	return result, rows.Err()
+}
+
+// GetStaleTokens returns tokens never enriched or last enriched longer ago than olderThan,
+// never-enriched and most valuable tokens first
+func (r *tokenMetadataRepository) GetStaleTokens(ctx context.Context, olderThan time.Duration, limit int) ([]*models.Token, error) {
+	query := `
+		SELECT t.id, t.address, t.chain_id, t.symbol, t.name
+		FROM tokens t
+		LEFT JOIN token_metadata m ON m.token_id = t.id
+		WHERE m.token_id IS NULL OR m.fetched_at < $1
+		ORDER BY m.fetched_at ASC NULLS FIRST, t.market_cap DESC NULLS LAST
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, time.Now().Add(-olderThan), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*models.Token
+	for rows.Next() {
+		var token models.Token
+		if err := rows.Scan(&token.ID, &token.Address, &token.ChainID, &token.Symbol, &token.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *tokenMetadataRepository) Upsert(ctx context.Context, metadata *models.TokenMetadata) error {
+	query := `
+		INSERT INTO token_metadata (
+			token_id, coingecko_id, categories, description, website, twitter,
+			telegram, discord, github, image_url, circulating_supply, not_found, fetched_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+		ON CONFLICT (token_id) DO UPDATE SET
+			coingecko_id = EXCLUDED.coingecko_id,
+			categories = EXCLUDED.categories,
+			description = EXCLUDED.description,
+			website = EXCLUDED.website,
+			twitter = EXCLUDED.twitter,
+			telegram = EXCLUDED.telegram,
+			discord = EXCLUDED.discord,
+			github = EXCLUDED.github,
+			image_url = EXCLUDED.image_url,
+			circulating_supply = EXCLUDED.circulating_supply,
+			not_found = EXCLUDED.not_found,
+			fetched_at = EXCLUDED.fetched_at
+		RETURNING fetched_at
+	`
+
+	categories := metadata.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+
+	err := r.db.QueryRow(ctx, query,
+		metadata.TokenID,
+		metadata.CoinGeckoID,
+		categories,
+		metadata.Description,
+		metadata.Website,
+		metadata.Twitter,
+		metadata.Telegram,
+		metadata.Discord,
+		metadata.GitHub,
+		metadata.ImageURL,
+		metadata.CirculatingSupply,
+		metadata.NotFound,
+	).Scan(&metadata.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert token metadata: %w", err)
+	}
+
+	return nil
+}
+
+// BoostPriceRefresh keeps a token's price refreshed on every price job run until the
+// given time, e.g. while users are looking at it
+func (r *tokenMetadataRepository) BoostPriceRefresh(ctx context.Context, tokenID uuid.UUID, until time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE tokens SET price_boosted_until = $2
+		WHERE id = $1 AND (price_boosted_until IS NULL OR price_boosted_until < $2)`,
+		tokenID, until)
+	if err != nil {
+		return fmt.Errorf("failed to boost token price refresh: %w", err)
+	}
+	return nil
+}
+
+// SavePrice stores a freshly fetched price for a token, returning when it was stored
+func (r *tokenMetadataRepository) SavePrice(ctx context.Context, tokenID uuid.UUID, priceUSD, priceChange24h float64) (time.Time, error) {
+	query := `
+		INSERT INTO token_prices_latest (token_id, price_usd, price_change_24h, updated_at)
+		SELECT id, $2, $3, NOW() FROM tokens WHERE id = $1
+		ON CONFLICT (token_id) DO UPDATE SET
+			price_usd = EXCLUDED.price_usd,
+			price_change_24h = EXCLUDED.price_change_24h,
+			updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	var savedAt time.Time
+	err := r.db.QueryRow(ctx, query, tokenID, priceUSD, priceChange24h).Scan(&savedAt)
+	if err == pgx.ErrNoRows {
+		return time.Time{}, ErrTokenNotFound
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to save token price: %w", err)
+	}
+	return savedAt, nil
+}
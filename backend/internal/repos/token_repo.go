@@ -2,6 +2,8 @@ package repos
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/pkg/utils"
@@ -134,3 +136,71 @@ func (r *tokenRepository) UpdatePrice(ctx context.Context, address string, chain
 	return token, nil
 }
 
+// GetTokensByAddresses returns the tokens on a chain with the given addresses, keyed by
+// lowercase address, with their latest price when known
+func (r *tokenRepository) GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error) {
+	result := make(map[string]*models.Token)
+	if len(addresses) == 0 {
+		return result, nil
+	}
+
+	lowered := make([]string, len(addresses))
+	for i, a := range addresses {
+		lowered[i] = strings.ToLower(a)
+	}
+
+	query := `
+		SELECT t.id, lower(t.address), t.chain_id, t.symbol, t.name, t.decimals, t.logo_uri, p.price_usd::float8
+		FROM tokens t
+		LEFT JOIN token_prices_latest p ON p.token_id = t.id
+		WHERE t.chain_id = $1 AND lower(t.address) = ANY($2)
+	`
+
+	rows, err := r.db.Query(ctx, query, chainID, lowered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token models.Token
+		if err := rows.Scan(&token.ID, &token.Address, &token.ChainID, &token.Symbol, &token.Name, &token.Decimals, &token.LogoURI, &token.PriceUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		result[token.Address] = &token
+	}
+
+	return result, rows.Err()
+}
+
+// UpsertTokens stores the symbol, name, decimals and logo of tokens, keeping a known logo
+// when a token comes without one. Prices are left untouched.
+func (r *tokenRepository) UpsertTokens(ctx context.Context, tokens []*models.Token) error {
+	query := `
+		INSERT INTO tokens (address, chain_id, symbol, name, decimals, logo_uri)
+		VALUES ($1, $2, LEFT($3, 50), LEFT($4, 255), $5, $6)
+		ON CONFLICT (address, chain_id) DO UPDATE SET
+			symbol = EXCLUDED.symbol,
+			name = EXCLUDED.name,
+			decimals = EXCLUDED.decimals,
+			logo_uri = COALESCE(EXCLUDED.logo_uri, tokens.logo_uri)
+	`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, token := range tokens {
+		_, err := tx.Exec(ctx, query, strings.ToLower(token.Address), token.ChainID, token.Symbol, token.Name, token.Decimals, token.LogoURI)
+		if err != nil {
+			return fmt.Errorf("failed to upsert token: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TransactionAnnotationRepository interface {
+	Upsert(ctx context.Context, annotation *models.TransactionAnnotation) error
+	Delete(ctx context.Context, userID uuid.UUID, transactionHash string) (bool, error)
+	GetByHashes(ctx context.Context, userID uuid.UUID, hashes []string) (map[string]*models.TransactionAnnotation, error)
+}
+
+type transactionAnnotationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTransactionAnnotationRepository(db *pgxpool.Pool) TransactionAnnotationRepository {
+	return &transactionAnnotationRepository{db: db}
+}
+
+// Upsert creates or replaces the user's annotation on a transaction
+func (r *transactionAnnotationRepository) Upsert(ctx context.Context, annotation *models.TransactionAnnotation) error {
+	query := `
+		INSERT INTO transaction_annotations (user_id, transaction_hash, category, tags, notes)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, transaction_hash) DO UPDATE SET
+			category = EXCLUDED.category,
+			tags = EXCLUDED.tags,
+			notes = EXCLUDED.notes
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		annotation.UserID,
+		annotation.TransactionHash,
+		annotation.Category,
+		annotation.Tags,
+		annotation.Notes,
+	).Scan(&annotation.ID, &annotation.CreatedAt, &annotation.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert transaction annotation: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the user's annotation on a transaction, returning false if there was none
+func (r *transactionAnnotationRepository) Delete(ctx context.Context, userID uuid.UUID, transactionHash string) (bool, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM transaction_annotations WHERE user_id = $1 AND transaction_hash = $2`, userID, transactionHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete transaction annotation: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetByHashes returns the user's annotations on the given transactions, keyed by
+// lowercase hash
+func (r *transactionAnnotationRepository) GetByHashes(ctx context.Context, userID uuid.UUID, hashes []string) (map[string]*models.TransactionAnnotation, error) {
+	query := `
+		SELECT id, user_id, transaction_hash, category, tags, notes, created_at, updated_at
+		FROM transaction_annotations
+		WHERE user_id = $1 AND transaction_hash = ANY($2)
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction annotations: %w", err)
+	}
+	defer rows.Close()
+
+	annotations := make(map[string]*models.TransactionAnnotation)
+	for rows.Next() {
+		var annotation models.TransactionAnnotation
+		err := rows.Scan(
+			&annotation.ID,
+			&annotation.UserID,
+			&annotation.TransactionHash,
+			&annotation.Category,
+			&annotation.Tags,
+			&annotation.Notes,
+			&annotation.CreatedAt,
+			&annotation.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction annotation: %w", err)
+		}
+		annotations[annotation.TransactionHash] = &annotation
+	}
+
+	return annotations, rows.Err()
+}
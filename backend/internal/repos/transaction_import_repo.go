@@ -0,0 +1,224 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ManualTransaction is an imported transaction with the PnL lot it creates
+type ManualTransaction struct {
+	Transaction *models.Transaction
+	Lot         *models.PnLLot
+}
+
+type TransactionImportRepository interface {
+	Create(ctx context.Context, imp *models.TransactionImport) error
+	GetByID(ctx context.Context, userID, importID uuid.UUID) (*models.TransactionImport, error)
+	SavePreview(ctx context.Context, imp *models.TransactionImport) error
+	ResolveTokens(ctx context.Context, chainID int, addresses, symbols []string) (map[string]uuid.UUID, error)
+	Commit(ctx context.Context, imp *models.TransactionImport, entries []ManualTransaction) (bool, error)
+}
+
+type transactionImportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTransactionImportRepository(db *pgxpool.Pool) TransactionImportRepository {
+	return &transactionImportRepository{db: db}
+}
+
+func (r *transactionImportRepository) Create(ctx context.Context, imp *models.TransactionImport) error {
+	query := `
+		INSERT INTO transaction_imports (user_id, wallet_id, upload_id, filename, status, row_count)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		imp.UserID,
+		imp.WalletID,
+		imp.UploadID,
+		imp.Filename,
+		imp.Status,
+		imp.RowCount,
+	).Scan(&imp.ID, &imp.CreatedAt, &imp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction import: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns one of the user's imports, or nil if there is none with that ID
+func (r *transactionImportRepository) GetByID(ctx context.Context, userID, importID uuid.UUID) (*models.TransactionImport, error) {
+	query := `
+		SELECT id, user_id, wallet_id, upload_id, filename, status, column_mapping,
+			   row_count, imported_count, committed_at, created_at, updated_at
+		FROM transaction_imports
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var imp models.TransactionImport
+	var mappingJSON []byte
+	err := r.db.QueryRow(ctx, query, importID, userID).Scan(
+		&imp.ID,
+		&imp.UserID,
+		&imp.WalletID,
+		&imp.UploadID,
+		&imp.Filename,
+		&imp.Status,
+		&mappingJSON,
+		&imp.RowCount,
+		&imp.ImportedCount,
+		&imp.CommittedAt,
+		&imp.CreatedAt,
+		&imp.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction import: %w", err)
+	}
+	if len(mappingJSON) > 0 {
+		if err := json.Unmarshal(mappingJSON, &imp.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal column mapping: %w", err)
+		}
+	}
+
+	return &imp, nil
+}
+
+// SavePreview stores the column mapping of a previewed import
+func (r *transactionImportRepository) SavePreview(ctx context.Context, imp *models.TransactionImport) error {
+	mappingJSON, err := json.Marshal(imp.Mapping)
+	if err != nil {
+		return fmt.Errorf("failed to marshal column mapping: %w", err)
+	}
+
+	query := `
+		UPDATE transaction_imports
+		SET column_mapping = $2, status = $3
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	if err := r.db.QueryRow(ctx, query, imp.ID, mappingJSON, imp.Status).Scan(&imp.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save transaction import preview: %w", err)
+	}
+	return nil
+}
+
+// ResolveTokens finds tokens on a chain by lowercase address or uppercase symbol, keyed
+// by the value matched. Symbols shared by several tokens on the chain are left out as
+// ambiguous.
+func (r *transactionImportRepository) ResolveTokens(ctx context.Context, chainID int, addresses, symbols []string) (map[string]uuid.UUID, error) {
+	query := `
+		SELECT lower(address), id
+		FROM tokens
+		WHERE chain_id = $1 AND lower(address) = ANY($2)
+		UNION ALL
+		SELECT upper(symbol), (array_agg(id))[1]
+		FROM tokens
+		WHERE chain_id = $1 AND upper(symbol) = ANY($3)
+		GROUP BY upper(symbol)
+		HAVING count(*) = 1
+	`
+
+	rows, err := r.db.Query(ctx, query, chainID, addresses, symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve import tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var key string
+		var id uuid.UUID
+		if err := rows.Scan(&key, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan import token: %w", err)
+		}
+		tokens[key] = id
+	}
+
+	return tokens, rows.Err()
+}
+
+// Commit marks a previewed import committed and stores its transactions, their links to
+// the user and their lots in one database transaction. It returns false, storing
+// nothing, if the import is no longer awaiting commit.
+func (r *transactionImportRepository) Commit(ctx context.Context, imp *models.TransactionImport, entries []ManualTransaction) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		UPDATE transaction_imports
+		SET status = $2, imported_count = $3, committed_at = NOW()
+		WHERE id = $1 AND status = $4
+		RETURNING committed_at, updated_at
+	`, imp.ID, models.ImportStatusCommitted, len(entries), models.ImportStatusPreviewed).Scan(&imp.CommittedAt, &imp.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark transaction import committed: %w", err)
+	}
+
+	txQuery := `
+		INSERT INTO transactions (hash, chain_id, from_address, to_address, timestamp, status, type, metadata, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	lotQuery := `
+		INSERT INTO pnl_lots (
+			id, wallet_id, token_id, transaction_hash, chain_id, type,
+			quantity, price_usd, remaining_quantity, block_number, timestamp, source
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	for _, entry := range entries {
+		t := entry.Transaction
+		t.FromAddress = addr.Normalize(t.FromAddress)
+		if t.ToAddress != nil {
+			to := addr.Normalize(*t.ToAddress)
+			t.ToAddress = &to
+		}
+		metadataJSON, err := json.Marshal(t.Metadata)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		err = tx.QueryRow(ctx, txQuery,
+			t.Hash, t.ChainID, t.FromAddress, t.ToAddress, t.Timestamp, t.Status, t.Type, metadataJSON, t.Source,
+		).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to create manual transaction: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO user_transactions (user_id, transaction_id, chain_id, wallet_id) VALUES ($1, $2, $3, $4)`,
+			imp.UserID, t.ID, t.ChainID, imp.WalletID); err != nil {
+			return false, fmt.Errorf("failed to link manual transaction: %w", err)
+		}
+
+		lot := entry.Lot
+		if _, err := tx.Exec(ctx, lotQuery,
+			lot.ID, lot.WalletID, lot.TokenID, lot.TransactionHash, lot.ChainID, lot.Type,
+			lot.Quantity, lot.PriceUSD, lot.RemainingQuantity, lot.BlockNumber, lot.Timestamp, lot.Source,
+		); err != nil {
+			return false, fmt.Errorf("failed to create manual lot: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction import: %w", err)
+	}
+	imp.Status = models.ImportStatusCommitted
+	imp.ImportedCount = len(entries)
+	return true, nil
+}
@@ -2,10 +2,15 @@ package repos
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -76,6 +81,384 @@ func (r *transactionRepository) LinkToUser(ctx context.Context, userID, transact
 	return nil
 }
 
+// Search returns the user's transactions matching filters, newest first, starting after the cursor.
+// A transaction linked to several of the user's wallets is returned once.
+func (r *transactionRepository) Search(ctx context.Context, userID uuid.UUID, filters TransactionSearchFilters) ([]*models.Transaction, error) {
+	query := `
+		WITH user_txs AS (
+			SELECT t.*,
+				   CASE WHEN lower(t.from_address) = lower(w.address)
+						THEN lower(t.to_address)
+						ELSE lower(t.from_address)
+				   END AS counterparty
+			FROM user_transactions ut
+			JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+			JOIN wallets w ON w.id = ut.wallet_id
+			WHERE ut.user_id = $1
+		)
+		SELECT DISTINCT ON (t.timestamp, t.id) ` + transactionColumns + `
+		FROM user_txs t
+		WHERE ($2::int[] IS NULL OR t.chain_id = ANY($2))
+		  AND ($3::text[] IS NULL OR t.type::text = ANY($3))
+		  AND ($4::text IS NULL OR lower(t.to_address) = $4
+			   OR lower(t.metadata->>'token_address') = $4
+			   OR lower(t.metadata->>'token_in') = $4
+			   OR lower(t.metadata->>'token_out') = $4)
+		  AND ($5::text IS NULL OR EXISTS (
+				SELECT 1 FROM address_labels l
+				WHERE l.user_id = $1 AND l.address = t.counterparty AND l.label ILIKE '%' || $5 || '%'
+				UNION ALL
+				SELECT 1 FROM wallets lw
+				WHERE lw.user_id = $1 AND lower(lw.address) = t.counterparty AND lw.label ILIKE '%' || $5 || '%'
+		  ))
+		  AND ($6::text IS NULL OR t.value >= ($6::text)::numeric)
+		  AND ($7::text IS NULL OR t.value <= ($7::text)::numeric)
+		  AND ($8::timestamptz IS NULL OR t.timestamp >= $8)
+		  AND ($9::timestamptz IS NULL OR t.timestamp < $9)
+		  AND ($10::text IS NULL OR t.method_search @@ to_tsquery('simple', $10))
+		  AND ($11::timestamptz IS NULL OR (t.timestamp, t.id) < ($11, $12::uuid))
+		  AND ($14::text[] IS NULL OR t.status::text = ANY($14))
+		  AND ($15::text[] IS NULL OR EXISTS (
+				SELECT 1 FROM transaction_annotations a
+				WHERE a.user_id = $1 AND a.transaction_hash = lower(t.hash) AND a.tags && $15
+		  ))
+		ORDER BY t.timestamp DESC, t.id DESC
+		LIMIT $13
+	`
+
+	var tsQuery *string
+	if q := buildPrefixTSQuery(filters.Text); q != "" {
+		tsQuery = &q
+	}
+	var cursorTime *time.Time
+	var cursorID *uuid.UUID
+	if filters.Cursor != nil {
+		cursorTime = &filters.Cursor.Timestamp
+		cursorID = &filters.Cursor.ID
+	}
+
+	rows, err := r.db.Query(ctx, query,
+		userID,
+		filters.ChainIDs,
+		filters.Types,
+		filters.Token,
+		filters.CounterpartyLabel,
+		filters.MinValue,
+		filters.MaxValue,
+		filters.From,
+		filters.To,
+		tsQuery,
+		cursorTime,
+		cursorID,
+		filters.Limit,
+		filters.Statuses,
+		filters.Tags,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+
+	return scanTransactions(rows)
+}
+
+// GetPending returns stored transactions awaiting finality, oldest first
+func (r *transactionRepository) GetPending(ctx context.Context, limit int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.status = 'pending'
+		ORDER BY t.created_at ASC
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending transactions: %w", err)
+	}
+
+	return scanTransactions(rows)
+}
+
+// GetUnfinalizedByAddress returns an address's pending transactions and those dropped in the last day
+func (r *transactionRepository) GetUnfinalizedByAddress(ctx context.Context, address string, chainID int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.chain_id = $2
+		  AND (lower(t.from_address) = lower($1) OR lower(t.to_address) = lower($1))
+		  AND (t.status = 'pending'
+			   OR (t.status = 'dropped' AND t.status_updated_at > NOW() - INTERVAL '1 day'))
+		ORDER BY t.timestamp DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, address, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unfinalized transactions: %w", err)
+	}
+
+	return scanTransactions(rows)
+}
+
+func (r *transactionRepository) GetByHashes(ctx context.Context, chainID int, hashes []string) (map[string]*models.Transaction, error) {
+	lowered := make([]string, len(hashes))
+	for i, hash := range hashes {
+		lowered[i] = strings.ToLower(hash)
+	}
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE t.chain_id = $1 AND t.hash = ANY($2)
+	`
+
+	rows, err := r.db.Query(ctx, query, chainID, lowered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions by hash: %w", err)
+	}
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+	byHash := make(map[string]*models.Transaction, len(transactions))
+	for _, tx := range transactions {
+		byHash[strings.ToLower(tx.Hash)] = tx
+	}
+	return byHash, nil
+}
+
+// UpdateConfirmations records a pending transaction's progress. Block fields are only
+// overwritten when set, and status_updated_at only moves when the status changes.
+func (r *transactionRepository) UpdateConfirmations(ctx context.Context, id uuid.UUID, chainID int, status string, confirmations int64, blockNumber *int64, blockHash *string) error {
+	query := `
+		UPDATE transactions
+		SET confirmations = $3,
+			block_number = COALESCE($4, block_number),
+			block_hash = COALESCE($5, block_hash),
+			status_updated_at = CASE WHEN status::text <> $2 THEN NOW() ELSE status_updated_at END,
+			status = $2::transaction_status
+		WHERE id = $1 AND chain_id = $6
+	`
+
+	tag, err := r.db.Exec(ctx, query, id, status, confirmations, blockNumber, blockHash, chainID)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction confirmations: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("transaction not found")
+	}
+
+	return nil
+}
+
+// GetOwnerIDs returns the users tracking a transaction through one of their wallets
+func (r *transactionRepository) GetOwnerIDs(ctx context.Context, transactionID uuid.UUID, chainID int) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `SELECT DISTINCT user_id FROM user_transactions WHERE transaction_id = $1 AND chain_id = $2`, transactionID, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction owners: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction owner: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// GetFeeSpend returns the gas the user's wallets paid per chain for on-chain transactions
+// they sent in [from, to)
+func (r *transactionRepository) GetFeeSpend(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]models.StatementFeeSpend, error) {
+	query := `
+		SELECT t.chain_id, COUNT(DISTINCT t.id), COALESCE(SUM(t.gas_fee_usd), 0)
+		FROM user_transactions ut
+		JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+		JOIN wallets w ON w.id = ut.wallet_id
+		WHERE ut.user_id = $1
+		  AND lower(t.from_address) = lower(w.address)
+		  AND t.source = 'onchain'
+		  AND t.poisoning_lookalike_of IS NULL
+		  AND t.timestamp >= $2 AND t.timestamp < $3
+		GROUP BY t.chain_id
+		ORDER BY t.chain_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee spend: %w", err)
+	}
+	defer rows.Close()
+
+	var fees []models.StatementFeeSpend
+	for rows.Next() {
+		var fee models.StatementFeeSpend
+		if err := rows.Scan(&fee.ChainID, &fee.TxCount, &fee.FeeUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan fee spend: %w", err)
+		}
+		fees = append(fees, fee)
+	}
+
+	return fees, rows.Err()
+}
+
+// GetGasUsage returns the gas paid per chain and transaction type by the user's wallets
+// at address for on-chain transactions they sent in [from, to) whose fee is priced
+func (r *transactionRepository) GetGasUsage(ctx context.Context, userID uuid.UUID, address string, from, to time.Time) ([]models.GasUsage, error) {
+	query := `
+		SELECT t.chain_id, t.type::text, COUNT(DISTINCT t.id), COALESCE(SUM(t.gas_used), 0), COALESCE(SUM(t.gas_fee_usd), 0)::float8
+		FROM user_transactions ut
+		JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+		JOIN wallets w ON w.id = ut.wallet_id
+		WHERE ut.user_id = $1
+		  AND lower(w.address) = lower($2)
+		  AND lower(t.from_address) = lower(w.address)
+		  AND t.source = 'onchain'
+		  AND t.poisoning_lookalike_of IS NULL
+		  AND t.gas_used IS NOT NULL AND t.gas_fee_usd IS NOT NULL
+		  AND t.timestamp >= $3 AND t.timestamp < $4
+		GROUP BY t.chain_id, t.type
+		ORDER BY t.chain_id, t.type
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, address, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []models.GasUsage
+	for rows.Next() {
+		var u models.GasUsage
+		if err := rows.Scan(&u.ChainID, &u.Type, &u.TxCount, &u.GasUsed, &u.FeeUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan gas usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
+
+// GetProtocolInteractions summarizes the user's transactions from the wallets at address
+// with known protocol contracts, per protocol and chain, most used first. Failed and
+// dropped transactions, and those suspected of address poisoning, are left out.
+func (r *transactionRepository) GetProtocolInteractions(ctx context.Context, userID uuid.UUID, address string) ([]*models.ProtocolInteraction, error) {
+	contracts, protocols := blockchain.ProtocolContracts()
+	query := `
+		WITH wallet_txs AS (
+			SELECT DISTINCT t.id, t.chain_id, t.value, t.timestamp,
+				   CASE WHEN lower(t.from_address) = lower(w.address)
+						THEN lower(t.to_address)
+						ELSE lower(t.from_address)
+				   END AS counterparty
+			FROM user_transactions ut
+			JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+			JOIN wallets w ON w.id = ut.wallet_id
+			WHERE ut.user_id = $1
+			  AND lower(w.address) = lower($2)
+			  AND t.status::text NOT IN ('failed', 'dropped')
+			  AND t.poisoning_lookalike_of IS NULL
+		)
+		SELECT c.protocol, t.chain_id, COUNT(*), COALESCE(SUM(t.value), 0)::text, MAX(t.timestamp)
+		FROM wallet_txs t
+		JOIN unnest($3::text[], $4::text[]) AS c(address, protocol) ON c.address = t.counterparty
+		GROUP BY c.protocol, t.chain_id
+		ORDER BY COUNT(*) DESC, c.protocol, t.chain_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, address, contracts, protocols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get protocol interactions: %w", err)
+	}
+	defer rows.Close()
+
+	interactions := []*models.ProtocolInteraction{}
+	for rows.Next() {
+		var interaction models.ProtocolInteraction
+		if err := rows.Scan(&interaction.Protocol, &interaction.ChainID, &interaction.TxCount, &interaction.Volume, &interaction.LastInteraction); err != nil {
+			return nil, fmt.Errorf("failed to scan protocol interaction: %w", err)
+		}
+		interaction.Name = blockchain.ProtocolName(interaction.Protocol)
+		interactions = append(interactions, &interaction)
+	}
+
+	return interactions, rows.Err()
+}
+
+// transactionColumns is the column list scanned by scanTransactions, qualified by alias t
+const transactionColumns = `t.id, t.hash, t.chain_id, t.from_address, t.to_address, t.value::text, t.gas_used,
+			   t.gas_price::text, t.gas_fee_usd, t.block_number, t.block_hash, t.timestamp, t.status, t.type,
+			   t.metadata, t.created_at, t.updated_at, t.confirmations, t.source, t.poisoning_lookalike_of`
+
+func scanTransactions(rows pgx.Rows) ([]*models.Transaction, error) {
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		var tx models.Transaction
+		var metadataJSON []byte
+		err := rows.Scan(
+			&tx.ID,
+			&tx.Hash,
+			&tx.ChainID,
+			&tx.FromAddress,
+			&tx.ToAddress,
+			&tx.Value,
+			&tx.GasUsed,
+			&tx.GasPrice,
+			&tx.GasFeeUSD,
+			&tx.BlockNumber,
+			&tx.BlockHash,
+			&tx.Timestamp,
+			&tx.Status,
+			&tx.Type,
+			&metadataJSON,
+			&tx.CreatedAt,
+			&tx.UpdatedAt,
+			&tx.Confirmations,
+			&tx.Source,
+			&tx.PoisoningLookalikeOf,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &tx.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+			}
+		}
+		transactions = append(transactions, &tx)
+	}
+
+	return transactions, rows.Err()
+}
+
+// buildPrefixTSQuery ANDs the words as prefix matches, e.g. ["swap", "exact"] -> "swap:* & exact:*".
+// Words are expected to be alphanumeric; anything else is dropped so user input can't inject tsquery syntax.
+func buildPrefixTSQuery(words []string) string {
+	var q string
+	for _, w := range words {
+		clean := make([]rune, 0, len(w))
+		for _, c := range w {
+			if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+				clean = append(clean, c)
+			}
+		}
+		if len(clean) == 0 {
+			continue
+		}
+		if q != "" {
+			q += " & "
+		}
+		q += string(clean) + ":*"
+	}
+	return q
+}
+
 func (r *transactionRepository) getMockTransactions() []*models.Transaction {
 	// Mock transaction data
 	toAddr1 := "0x0987654321098765432109876543210987654321"
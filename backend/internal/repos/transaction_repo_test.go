@@ -0,0 +1,13 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPrefixTSQuery(t *testing.T) {
+	assert.Equal(t, "swap:* & exact:*", buildPrefixTSQuery([]string{"swap", "exact"}))
+	assert.Equal(t, "approve:*", buildPrefixTSQuery([]string{"approve", "&|!", ""}))
+	assert.Equal(t, "", buildPrefixTSQuery(nil))
+}
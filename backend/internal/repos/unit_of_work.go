@@ -0,0 +1,92 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UnitOfWork runs several repository calls as one transaction, so a service making
+// more than one write can't leave half of them behind when a later one fails
+type UnitOfWork interface {
+	// Do runs fn in a transaction, committing it when fn returns nil and rolling it back
+	// otherwise. Repository calls made with the ctx fn is given join the transaction; a
+	// Do inside another runs in a savepoint, so its failure only undoes its own writes.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type unitOfWork struct {
+	db txDB
+}
+
+// NewUnitOfWork creates a unit of work over the pool. Only repositories holding a txDB
+// join its transactions.
+func NewUnitOfWork(db *pgxpool.Pool) UnitOfWork {
+	return &unitOfWork{db: txDB{pool: db}}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// NoUnitOfWork runs fn directly, each repository call committing on its own. It's for
+// services built without a database, like in tests.
+var NoUnitOfWork UnitOfWork = noUnitOfWork{}
+
+type noUnitOfWork struct{}
+
+func (noUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// txDB runs statements in the transaction of the unit of work on ctx, and on the pool
+// outside one
+type txDB struct {
+	pool *pgxpool.Pool
+}
+
+func (db txDB) conn(ctx context.Context) interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+} {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.pool
+}
+
+func (db txDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return db.conn(ctx).Exec(ctx, sql, args...)
+}
+
+func (db txDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return db.conn(ctx).Query(ctx, sql, args...)
+}
+
+func (db txDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return db.conn(ctx).QueryRow(ctx, sql, args...)
+}
+
+// Begin starts a transaction, or a savepoint of the unit of work's
+func (db txDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return db.conn(ctx).Begin(ctx)
+}
@@ -0,0 +1,121 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UploadRepository interface {
+	Create(ctx context.Context, upload *models.Upload) error
+	GetByID(ctx context.Context, userID, uploadID uuid.UUID) (*models.Upload, error)
+	GetExpired(ctx context.Context, now time.Time, limit int) ([]*models.Upload, error)
+	Delete(ctx context.Context, uploadID uuid.UUID) error
+}
+
+type uploadRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUploadRepository(db *pgxpool.Pool) UploadRepository {
+	return &uploadRepository{db: db}
+}
+
+const uploadColumns = `id, user_id, kind, storage_key, filename, content_type, size_bytes, expires_at, created_at`
+
+func scanUpload(row pgx.Row) (*models.Upload, error) {
+	var upload models.Upload
+	err := row.Scan(
+		&upload.ID,
+		&upload.UserID,
+		&upload.Kind,
+		&upload.StorageKey,
+		&upload.Filename,
+		&upload.ContentType,
+		&upload.SizeBytes,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Create records an upload. The ID is set by the caller, as it is part of the storage key.
+func (r *uploadRepository) Create(ctx context.Context, upload *models.Upload) error {
+	query := `
+		INSERT INTO uploads (id, user_id, kind, storage_key, filename, content_type, size_bytes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		upload.ID,
+		upload.UserID,
+		upload.Kind,
+		upload.StorageKey,
+		upload.Filename,
+		upload.ContentType,
+		upload.SizeBytes,
+		upload.ExpiresAt,
+	).Scan(&upload.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+	return nil
+}
+
+// GetByID returns one of the user's uploads, or nil if there is none with that ID
+func (r *uploadRepository) GetByID(ctx context.Context, userID, uploadID uuid.UUID) (*models.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM uploads WHERE id = $1 AND user_id = $2`
+
+	upload, err := scanUpload(r.db.QueryRow(ctx, query, uploadID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload: %w", err)
+	}
+	return upload, nil
+}
+
+// GetExpired returns up to limit uploads whose expiry is at or before now, oldest first
+func (r *uploadRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]*models.Upload, error) {
+	query := `
+		SELECT ` + uploadColumns + `
+		FROM uploads
+		WHERE expires_at IS NOT NULL AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploads []*models.Upload
+	for rows.Next() {
+		upload, err := scanUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan upload: %w", err)
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads, rows.Err()
+}
+
+func (r *uploadRepository) Delete(ctx context.Context, uploadID uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM uploads WHERE id = $1`, uploadID); err != nil {
+		return fmt.Errorf("failed to delete upload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UsageFlagRepository interface {
+	Create(ctx context.Context, flag *models.UsageFlag) error
+	// List returns the latest flags, newest first; activeOnly keeps those still
+	// restricting their subject at the time given
+	List(ctx context.Context, activeOnly bool, at time.Time, limit int) ([]*models.UsageFlag, error)
+	// GetActive returns every flag still restricting its subject at the time given
+	GetActive(ctx context.Context, at time.Time) ([]*models.UsageFlag, error)
+	// Resolve lifts a flag's restriction, reporting whether it was unresolved
+	Resolve(ctx context.Context, id, resolvedBy uuid.UUID, at time.Time) (bool, error)
+}
+
+type usageFlagRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUsageFlagRepository(db *pgxpool.Pool) UsageFlagRepository {
+	return &usageFlagRepository{db: db}
+}
+
+const usageFlagColumns = `id, subject_type, subject, user_id, reason, details, restricted_until, resolved_at, resolved_by, created_at`
+
+func scanUsageFlag(row pgx.Row) (*models.UsageFlag, error) {
+	var f models.UsageFlag
+	err := row.Scan(
+		&f.ID,
+		&f.SubjectType,
+		&f.Subject,
+		&f.UserID,
+		&f.Reason,
+		&f.Details,
+		&f.RestrictedUntil,
+		&f.ResolvedAt,
+		&f.ResolvedBy,
+		&f.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *usageFlagRepository) Create(ctx context.Context, flag *models.UsageFlag) error {
+	if flag.Details == nil {
+		flag.Details = map[string]interface{}{}
+	}
+	query := `
+		INSERT INTO usage_flags (subject_type, subject, user_id, reason, details, restricted_until)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + usageFlagColumns
+
+	created, err := scanUsageFlag(r.db.QueryRow(ctx, query,
+		flag.SubjectType, flag.Subject, flag.UserID, flag.Reason, flag.Details, flag.RestrictedUntil,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create usage flag: %w", err)
+	}
+	*flag = *created
+	return nil
+}
+
+func (r *usageFlagRepository) List(ctx context.Context, activeOnly bool, at time.Time, limit int) ([]*models.UsageFlag, error) {
+	var where whereBuilder
+	if activeOnly {
+		where.add("resolved_at IS NULL AND restricted_until > ?", at)
+	}
+	query := `SELECT ` + usageFlagColumns + ` FROM usage_flags ` + where.clause() + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + where.arg(limit)
+
+	return r.query(ctx, query, where.args...)
+}
+
+func (r *usageFlagRepository) GetActive(ctx context.Context, at time.Time) ([]*models.UsageFlag, error) {
+	query := `SELECT ` + usageFlagColumns + ` FROM usage_flags
+		WHERE resolved_at IS NULL AND restricted_until > $1
+		ORDER BY created_at, id`
+
+	return r.query(ctx, query, at)
+}
+
+func (r *usageFlagRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.UsageFlag, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []*models.UsageFlag{}
+	for rows.Next() {
+		flag, err := scanUsageFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan usage flag: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+func (r *usageFlagRepository) Resolve(ctx context.Context, id, resolvedBy uuid.UUID, at time.Time) (bool, error) {
+	result, err := r.db.Exec(ctx, `
+		UPDATE usage_flags SET resolved_at = $3, resolved_by = $2
+		WHERE id = $1 AND resolved_at IS NULL`, id, resolvedBy, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve usage flag: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
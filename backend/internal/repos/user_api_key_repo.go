@@ -0,0 +1,143 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrAPIKeyNotFound is returned when the user has no key stored for the provider. It
+// wraps ErrNotFound.
+var ErrAPIKeyNotFound = fmt.Errorf("api key %w", ErrNotFound)
+
+type UserAPIKeyRepository interface {
+	Upsert(ctx context.Context, userID uuid.UUID, provider string, encryptedKey []byte, keyHint string) (*models.UserAPIKey, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserAPIKey, error)
+	GetEncryptedKeys(ctx context.Context, userID uuid.UUID) (map[string][]byte, error)
+	Delete(ctx context.Context, userID uuid.UUID, provider string) error
+	IncrementUsage(ctx context.Context, userID uuid.UUID, provider string) error
+}
+
+type userAPIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserAPIKeyRepository(db *pgxpool.Pool) UserAPIKeyRepository {
+	return &userAPIKeyRepository{db: db}
+}
+
+func (r *userAPIKeyRepository) Upsert(ctx context.Context, userID uuid.UUID, provider string, encryptedKey []byte, keyHint string) (*models.UserAPIKey, error) {
+	query := `
+		INSERT INTO user_api_keys (user_id, provider, encrypted_key, key_hint)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, provider) DO UPDATE SET
+			encrypted_key = EXCLUDED.encrypted_key,
+			key_hint = EXCLUDED.key_hint,
+			usage_count = 0,
+			last_used_at = NULL
+		RETURNING id, user_id, provider, key_hint, usage_count, last_used_at, created_at, updated_at
+	`
+
+	var key models.UserAPIKey
+	err := r.db.QueryRow(ctx, query, userID, provider, encryptedKey, keyHint).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Provider,
+		&key.KeyHint,
+		&key.UsageCount,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *userAPIKeyRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserAPIKey, error) {
+	query := `
+		SELECT id, user_id, provider, key_hint, usage_count, last_used_at, created_at, updated_at
+		FROM user_api_keys
+		WHERE user_id = $1
+		ORDER BY provider
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.UserAPIKey
+	for rows.Next() {
+		var key models.UserAPIKey
+		err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Provider,
+			&key.KeyHint,
+			&key.UsageCount,
+			&key.LastUsedAt,
+			&key.CreatedAt,
+			&key.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *userAPIKeyRepository) GetEncryptedKeys(ctx context.Context, userID uuid.UUID) (map[string][]byte, error) {
+	rows, err := r.db.Query(ctx, `SELECT provider, encrypted_key FROM user_api_keys WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string][]byte)
+	for rows.Next() {
+		var provider string
+		var encrypted []byte
+		if err := rows.Scan(&provider, &encrypted); err != nil {
+			return nil, fmt.Errorf("failed to scan encrypted api key: %w", err)
+		}
+		keys[provider] = encrypted
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *userAPIKeyRepository) Delete(ctx context.Context, userID uuid.UUID, provider string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM user_api_keys WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *userAPIKeyRepository) IncrementUsage(ctx context.Context, userID uuid.UUID, provider string) error {
+	query := `
+		UPDATE user_api_keys
+		SET usage_count = usage_count + 1, last_used_at = NOW()
+		WHERE user_id = $1 AND provider = $2
+	`
+
+	if _, err := r.db.Exec(ctx, query, userID, provider); err != nil {
+		return fmt.Errorf("failed to record api key usage: %w", err)
+	}
+
+	return nil
+}
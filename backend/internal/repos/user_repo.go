@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	addr "github.com/defi-dashboard/backend/pkg/address"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -26,12 +27,12 @@ func (r *userRepository) GetByAddress(ctx context.Context, address string) (*mod
 	`
 	
 	var user models.User
-	err := r.db.QueryRow(ctx, query, address).Scan(
+	err := r.db.QueryRow(ctx, query, addr.Normalize(address)).Scan(
 		&user.ID, &user.Address, &user.Email, &user.Nonce, &user.IsAdmin,
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "user")
 	}
 
 	return &user, nil
@@ -50,7 +51,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "user")
 	}
 
 	return &user, nil
@@ -64,12 +65,12 @@ func (r *userRepository) Create(ctx context.Context, address, nonce string) (*mo
 	`
 	
 	var user models.User
-	err := r.db.QueryRow(ctx, query, address, nonce).Scan(
+	err := r.db.QueryRow(ctx, query, addr.Normalize(address), nonce).Scan(
 		&user.ID, &user.Address, &user.Email, &user.Nonce, 
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "user")
 	}
 
 	return &user, nil
@@ -84,7 +85,7 @@ func (r *userRepository) UpdateNonce(ctx context.Context, address, nonce string)
 	`
 	
 	var user models.User
-	err := r.db.QueryRow(ctx, query, address, nonce).Scan(
+	err := r.db.QueryRow(ctx, query, addr.Normalize(address), nonce).Scan(
 		&user.ID, &user.Address, &user.Email, &user.Nonce, 
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
@@ -108,7 +109,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "user")
 	}
 
 	return &user, nil
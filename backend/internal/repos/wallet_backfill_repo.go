@@ -0,0 +1,374 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WalletBackfillRepository interface {
+	// EnqueueMissing queues a backfill for every EVM wallet without one, returning the
+	// backfills queued with only their wallet, owner and chain set
+	EnqueueMissing(ctx context.Context) ([]*models.WalletBackfill, error)
+	// GetByWalletID returns nil when the wallet has no backfill yet
+	GetByWalletID(ctx context.Context, walletID uuid.UUID) (*models.WalletBackfill, error)
+	// GetActive lists pending and running backfills, oldest first
+	GetActive(ctx context.Context, limit int) ([]*models.WalletBackfill, error)
+	// Start marks a backfill running up to the target block
+	Start(ctx context.Context, backfill *models.WalletBackfill, targetBlock int64) error
+	// SaveChunk stores the transactions found in a chunk of blocks and links them to the
+	// wallet's owner, counting those that suggest wallet labels, then moves the backfill
+	// on to nextBlock, completing it past the target block. It updates backfill in place.
+	SaveChunk(ctx context.Context, backfill *models.WalletBackfill, transactions []*models.Transaction, nextBlock int64) error
+	// SaveTransactions stores transactions seen outside the backfill, such as those a
+	// webhook reported, linking them to the wallet's owner like SaveChunk. It returns how
+	// many were newly linked.
+	SaveTransactions(ctx context.Context, wallet *models.Wallet, transactions []*models.Transaction) (int, error)
+	// CopyFromPeer completes a backfill that hasn't started from the completed backfill of
+	// another wallet tracking the same address on the chain, linking the transactions it
+	// imported, and the label interactions counted, to this wallet instead of fetching
+	// them again. It reports whether there was such a backfill, updating backfill in
+	// place when there was.
+	CopyFromPeer(ctx context.Context, backfill *models.WalletBackfill) (bool, error)
+	// RecordError counts a failed chunk, failing the backfill after maxAttempts in a row
+	RecordError(ctx context.Context, id uuid.UUID, message string, maxAttempts int) error
+}
+
+type walletBackfillRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletBackfillRepository(db *pgxpool.Pool) WalletBackfillRepository {
+	return &walletBackfillRepository{db: db}
+}
+
+const walletBackfillColumns = `b.id, b.wallet_id, w.user_id, w.address, b.chain_id, b.status,
+	b.target_block, b.next_block, b.transactions_found, b.attempts, b.error,
+	b.started_at, b.completed_at, b.created_at, b.updated_at`
+
+func scanWalletBackfill(row pgx.Row) (*models.WalletBackfill, error) {
+	var b models.WalletBackfill
+	err := row.Scan(
+		&b.ID,
+		&b.WalletID,
+		&b.UserID,
+		&b.WalletAddress,
+		&b.ChainID,
+		&b.Status,
+		&b.TargetBlock,
+		&b.NextBlock,
+		&b.TransactionsFound,
+		&b.Attempts,
+		&b.Error,
+		&b.StartedAt,
+		&b.CompletedAt,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *walletBackfillRepository) EnqueueMissing(ctx context.Context) ([]*models.WalletBackfill, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH queued AS (
+			INSERT INTO wallet_backfills (wallet_id, chain_id)
+			SELECT w.id, w.chain_id
+			FROM wallets w
+			WHERE w.chain_namespace = $1 AND w.removed_at IS NULL
+			  AND NOT EXISTS (SELECT 1 FROM wallet_backfills b WHERE b.wallet_id = w.id)
+			ON CONFLICT (wallet_id) DO NOTHING
+			RETURNING wallet_id, chain_id
+		)
+		SELECT q.wallet_id, w.user_id, q.chain_id
+		FROM queued q
+		JOIN wallets w ON w.id = q.wallet_id`, models.ChainNamespaceEVM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue wallet backfills: %w", err)
+	}
+	defer rows.Close()
+
+	queued := []*models.WalletBackfill{}
+	for rows.Next() {
+		backfill := &models.WalletBackfill{Status: models.BackfillStatusPending}
+		if err := rows.Scan(&backfill.WalletID, &backfill.UserID, &backfill.ChainID); err != nil {
+			return nil, fmt.Errorf("failed to scan queued wallet backfill: %w", err)
+		}
+		queued = append(queued, backfill)
+	}
+	return queued, rows.Err()
+}
+
+func (r *walletBackfillRepository) GetByWalletID(ctx context.Context, walletID uuid.UUID) (*models.WalletBackfill, error) {
+	query := `
+		SELECT ` + walletBackfillColumns + `
+		FROM wallet_backfills b
+		JOIN wallets w ON w.id = b.wallet_id
+		WHERE b.wallet_id = $1`
+
+	backfill, err := scanWalletBackfill(r.db.QueryRow(ctx, query, walletID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet backfill: %w", err)
+	}
+	return backfill, nil
+}
+
+func (r *walletBackfillRepository) GetActive(ctx context.Context, limit int) ([]*models.WalletBackfill, error) {
+	query := `
+		SELECT ` + walletBackfillColumns + `
+		FROM wallet_backfills b
+		JOIN wallets w ON w.id = b.wallet_id
+		WHERE b.status IN ($1, $2)
+		ORDER BY b.created_at, b.id
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, models.BackfillStatusPending, models.BackfillStatusRunning, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active wallet backfills: %w", err)
+	}
+	defer rows.Close()
+
+	var backfills []*models.WalletBackfill
+	for rows.Next() {
+		backfill, err := scanWalletBackfill(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet backfill: %w", err)
+		}
+		backfills = append(backfills, backfill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wallet backfills: %w", err)
+	}
+	return backfills, nil
+}
+
+func (r *walletBackfillRepository) Start(ctx context.Context, backfill *models.WalletBackfill, targetBlock int64) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE wallet_backfills
+		SET status = $2, target_block = $3, started_at = COALESCE(started_at, NOW())
+		WHERE id = $1
+		RETURNING status, target_block, started_at, updated_at`,
+		backfill.ID, models.BackfillStatusRunning, targetBlock,
+	).Scan(&backfill.Status, &backfill.TargetBlock, &backfill.StartedAt, &backfill.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to start wallet backfill: %w", err)
+	}
+	return nil
+}
+
+func (r *walletBackfillRepository) SaveChunk(ctx context.Context, backfill *models.WalletBackfill, transactions []*models.Transaction, nextBlock int64) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	linked, err := storeWalletTransactions(ctx, tx, backfill.UserID, backfill.WalletID, backfill.WalletAddress, transactions)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE wallet_backfills
+		SET next_block = $2,
+			transactions_found = transactions_found + $3,
+			attempts = 0,
+			error = NULL,
+			status = CASE WHEN $2 > target_block THEN $4 ELSE status END,
+			completed_at = CASE WHEN $2 > target_block THEN NOW() ELSE completed_at END
+		WHERE id = $1
+		RETURNING status, next_block, transactions_found, attempts, error, completed_at, updated_at`,
+		backfill.ID, nextBlock, linked, models.BackfillStatusCompleted,
+	).Scan(&backfill.Status, &backfill.NextBlock, &backfill.TransactionsFound, &backfill.Attempts,
+		&backfill.Error, &backfill.CompletedAt, &backfill.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save wallet backfill progress: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit wallet backfill chunk: %w", err)
+	}
+	return nil
+}
+
+func (r *walletBackfillRepository) SaveTransactions(ctx context.Context, wallet *models.Wallet, transactions []*models.Transaction) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	linked, err := storeWalletTransactions(ctx, tx, wallet.UserID, wallet.ID, wallet.Address, transactions)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit wallet transactions: %w", err)
+	}
+	return linked, nil
+}
+
+func (r *walletBackfillRepository) CopyFromPeer(ctx context.Context, backfill *models.WalletBackfill) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var peerWalletID uuid.UUID
+	var targetBlock int64
+	err = tx.QueryRow(ctx, `
+		SELECT b.wallet_id, b.target_block
+		FROM wallet_backfills b
+		JOIN wallets w ON w.id = b.wallet_id
+		WHERE b.status = $1
+		  AND b.chain_id = $2
+		  AND b.wallet_id <> $3
+		  AND w.chain_namespace = $4
+		  AND w.address = $5
+		ORDER BY b.target_block DESC, b.completed_at
+		LIMIT 1`,
+		models.BackfillStatusCompleted, backfill.ChainID, backfill.WalletID, models.ChainNamespaceEVM, backfill.WalletAddress,
+	).Scan(&peerWalletID, &targetBlock)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find peer wallet backfill: %w", err)
+	}
+
+	// Only on-chain transactions the peer's backfill covers are shared; anything its owner
+	// imported by hand stays theirs
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO user_transactions (user_id, transaction_id, chain_id, wallet_id)
+		SELECT $1, ut.transaction_id, ut.chain_id, $2
+		FROM user_transactions ut
+		JOIN transactions t ON t.id = ut.transaction_id AND t.chain_id = ut.chain_id
+		WHERE ut.wallet_id = $3 AND ut.chain_id = $4 AND t.block_number <= $5
+		ON CONFLICT DO NOTHING`,
+		backfill.UserID, backfill.WalletID, peerWalletID, backfill.ChainID, targetBlock)
+	if err != nil {
+		return false, fmt.Errorf("failed to link peer transactions: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO wallet_label_suggestions (wallet_id, label, interactions)
+		SELECT $1, label, interactions FROM wallet_label_suggestions WHERE wallet_id = $2
+		ON CONFLICT (wallet_id, label) DO UPDATE SET interactions = EXCLUDED.interactions`,
+		backfill.WalletID, peerWalletID)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy peer wallet labels: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE wallet_backfills
+		SET status = $2,
+			target_block = $3,
+			next_block = $3 + 1,
+			transactions_found = $4,
+			attempts = 0,
+			error = NULL,
+			started_at = COALESCE(started_at, NOW()),
+			completed_at = NOW()
+		WHERE id = $1
+		RETURNING status, target_block, next_block, transactions_found, attempts, error, started_at, completed_at, updated_at`,
+		backfill.ID, models.BackfillStatusCompleted, targetBlock, tag.RowsAffected(),
+	).Scan(&backfill.Status, &backfill.TargetBlock, &backfill.NextBlock, &backfill.TransactionsFound,
+		&backfill.Attempts, &backfill.Error, &backfill.StartedAt, &backfill.CompletedAt, &backfill.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete wallet backfill from peer: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit wallet backfill: %w", err)
+	}
+	return true, nil
+}
+
+func (r *walletBackfillRepository) RecordError(ctx context.Context, id uuid.UUID, message string, maxAttempts int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE wallet_backfills
+		SET attempts = attempts + 1,
+			error = $2,
+			status = CASE WHEN attempts + 1 >= $3 THEN $4 ELSE status END
+		WHERE id = $1`,
+		id, message, maxAttempts, models.BackfillStatusFailed)
+	if err != nil {
+		return fmt.Errorf("failed to record wallet backfill error: %w", err)
+	}
+	return nil
+}
+
+// storeWalletTransactions stores transactions found for a wallet and links them to its
+// owner, counting those that suggest wallet labels. Transactions the wallet already had
+// aren't counted again. It returns how many were newly linked.
+func storeWalletTransactions(ctx context.Context, tx pgx.Tx, userID, walletID uuid.UUID, walletAddress string, transactions []*models.Transaction) (int, error) {
+	// A transaction already stored, e.g. from another user's wallet, keeps its row, only
+	// taking gas figures it's missing; the update returns its ID so it can still be linked
+	txQuery := `
+		INSERT INTO transactions (hash, chain_id, from_address, to_address, value, block_number, timestamp, status, type, metadata,
+			gas_used, gas_price, gas_fee_usd, block_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (hash, chain_id) DO UPDATE SET
+			block_hash = COALESCE(transactions.block_hash, EXCLUDED.block_hash),
+			gas_used = COALESCE(transactions.gas_used, EXCLUDED.gas_used),
+			gas_price = COALESCE(transactions.gas_price, EXCLUDED.gas_price),
+			gas_fee_usd = COALESCE(transactions.gas_fee_usd, EXCLUDED.gas_fee_usd)
+		RETURNING id`
+	var linked []*models.Transaction
+	for _, t := range transactions {
+		var to *string
+		if t.ToAddress != nil {
+			normalized := addr.Normalize(*t.ToAddress)
+			to = &normalized
+		}
+		metadataJSON, err := json.Marshal(t.Metadata)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+
+		var id uuid.UUID
+		err = tx.QueryRow(ctx, txQuery,
+			strings.ToLower(t.Hash), t.ChainID, addr.Normalize(t.FromAddress), to, t.Value,
+			t.BlockNumber, t.Timestamp, t.Status, t.Type, metadataJSON,
+			t.GasUsed, t.GasPrice, t.GasFeeUSD, t.BlockHash,
+		).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to store transaction %s: %w", t.Hash, err)
+		}
+
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO user_transactions (user_id, transaction_id, chain_id, wallet_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`, userID, id, t.ChainID, walletID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to link transaction %s: %w", t.Hash, err)
+		}
+		if tag.RowsAffected() > 0 {
+			linked = append(linked, t)
+		}
+	}
+
+	// Suspected address poisoning doesn't suggest labels
+	genuine, err := flagAddressPoisoning(ctx, tx, userID, walletID, walletAddress, linked)
+	if err != nil {
+		return 0, err
+	}
+	if err := addWalletLabelInteractions(ctx, tx, walletID, blockchain.CountLabelInteractions(walletAddress, genuine)); err != nil {
+		return 0, err
+	}
+	return len(linked), nil
+}
@@ -0,0 +1,193 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrWalletGroupNameTaken is returned when the user already has a group with the name
+var ErrWalletGroupNameTaken = errors.New("wallet group name already taken")
+
+type WalletGroupRepository interface {
+	// Create stores the group and moves its wallets into it
+	Create(ctx context.Context, group *models.WalletGroup) error
+	// GetByID returns nil when there's no group with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WalletGroup, error)
+	// GetByUser lists the user's groups by name
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WalletGroup, error)
+	// Update saves the name and description and replaces the group's wallets
+	Update(ctx context.Context, group *models.WalletGroup) error
+	// Delete reports whether the user had a group with the ID; its wallets are ungrouped
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+}
+
+type walletGroupRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletGroupRepository(db *pgxpool.Pool) WalletGroupRepository {
+	return &walletGroupRepository{db: db}
+}
+
+// walletGroupColumns reads a group with its wallets from wallet_groups g
+const walletGroupColumns = `g.id, g.user_id, g.name, g.description,
+	COALESCE((SELECT array_agg(w.id ORDER BY w.created_at, w.id) FROM wallets w WHERE w.group_id = g.id AND w.removed_at IS NULL), '{}'),
+	g.created_at, g.updated_at`
+
+func scanWalletGroup(row pgx.Row) (*models.WalletGroup, error) {
+	var g models.WalletGroup
+	err := row.Scan(
+		&g.ID,
+		&g.UserID,
+		&g.Name,
+		&g.Description,
+		&g.WalletIDs,
+		&g.CreatedAt,
+		&g.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if g.WalletIDs == nil {
+		g.WalletIDs = []uuid.UUID{}
+	}
+	return &g, nil
+}
+
+func (r *walletGroupRepository) Create(ctx context.Context, group *models.WalletGroup) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO wallet_groups (user_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id`,
+		group.UserID, group.Name, group.Description,
+	).Scan(&group.ID)
+	if isUniqueViolation(err, "wallet_groups_user_id_name_key") {
+		return ErrWalletGroupNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create wallet group: %w", err)
+	}
+
+	if err := r.saveGroup(ctx, tx, group); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit wallet group: %w", err)
+	}
+	return nil
+}
+
+func (r *walletGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WalletGroup, error) {
+	query := `SELECT ` + walletGroupColumns + ` FROM wallet_groups g WHERE g.id = $1`
+
+	group, err := scanWalletGroup(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet group: %w", err)
+	}
+	return group, nil
+}
+
+func (r *walletGroupRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WalletGroup, error) {
+	query := `
+		SELECT ` + walletGroupColumns + `
+		FROM wallet_groups g
+		WHERE g.user_id = $1
+		ORDER BY g.name, g.id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []*models.WalletGroup{}
+	for rows.Next() {
+		group, err := scanWalletGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+func (r *walletGroupRepository) Update(ctx context.Context, group *models.WalletGroup) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE wallet_groups
+		SET name = $3, description = $4
+		WHERE id = $1 AND user_id = $2`,
+		group.ID, group.UserID, group.Name, group.Description)
+	if isUniqueViolation(err, "wallet_groups_user_id_name_key") {
+		return ErrWalletGroupNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update wallet group: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("failed to update wallet group: %w", pgx.ErrNoRows)
+	}
+
+	if err := r.saveGroup(ctx, tx, group); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit wallet group: %w", err)
+	}
+	return nil
+}
+
+// saveGroup makes the group's wallets exactly group.WalletIDs, then reads the group back
+// into group. Only the owner's wallets are moved.
+func (r *walletGroupRepository) saveGroup(ctx context.Context, tx pgx.Tx, group *models.WalletGroup) error {
+	walletIDs := group.WalletIDs
+	if walletIDs == nil {
+		walletIDs = []uuid.UUID{}
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE wallets SET group_id = NULL
+		WHERE group_id = $1 AND NOT (id = ANY($2))`, group.ID, walletIDs); err != nil {
+		return fmt.Errorf("failed to remove wallets from group: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE wallets SET group_id = $1
+		WHERE user_id = $2 AND id = ANY($3)`, group.ID, group.UserID, walletIDs); err != nil {
+		return fmt.Errorf("failed to add wallets to group: %w", err)
+	}
+
+	saved, err := scanWalletGroup(tx.QueryRow(ctx, `SELECT `+walletGroupColumns+` FROM wallet_groups g WHERE g.id = $1`, group.ID))
+	if err != nil {
+		return fmt.Errorf("failed to read wallet group: %w", err)
+	}
+	*group = *saved
+	return nil
+}
+
+func (r *walletGroupRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM wallet_groups WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete wallet group: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
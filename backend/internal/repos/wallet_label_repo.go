@@ -0,0 +1,93 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WalletLabelRepository serves the labels suggested for wallets. Interactions are
+// counted by the wallet backfill repository as it stores the wallet's history.
+type WalletLabelRepository interface {
+	// GetByWallet returns the wallet's accepted labels and the suggested ones with at
+	// least minInteractions, most interactions first
+	GetByWallet(ctx context.Context, walletID uuid.UUID, minInteractions int) ([]*models.WalletLabelSuggestion, error)
+	// SetStatus accepts or dismisses a label, returning nil when the wallet has no such label
+	SetStatus(ctx context.Context, walletID uuid.UUID, label, status string) (*models.WalletLabelSuggestion, error)
+}
+
+type walletLabelRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletLabelRepository(db *pgxpool.Pool) WalletLabelRepository {
+	return &walletLabelRepository{db: db}
+}
+
+const walletLabelColumns = `wallet_id, label, interactions, status, created_at, updated_at`
+
+func scanWalletLabel(row pgx.Row) (*models.WalletLabelSuggestion, error) {
+	var s models.WalletLabelSuggestion
+	if err := row.Scan(&s.WalletID, &s.Label, &s.Interactions, &s.Status, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// addWalletLabelInteractions adds to the interactions counted for the wallet's labels as
+// part of tx, so they're counted once with the transactions behind them
+func addWalletLabelInteractions(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, counts map[string]int) error {
+	for label, count := range counts {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO wallet_label_suggestions (wallet_id, label, interactions)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (wallet_id, label) DO UPDATE SET
+				interactions = wallet_label_suggestions.interactions + EXCLUDED.interactions`,
+			walletID, label, count)
+		if err != nil {
+			return fmt.Errorf("failed to add wallet label interactions: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *walletLabelRepository) GetByWallet(ctx context.Context, walletID uuid.UUID, minInteractions int) ([]*models.WalletLabelSuggestion, error) {
+	query := `SELECT ` + walletLabelColumns + ` FROM wallet_label_suggestions
+		WHERE wallet_id = $1 AND (status = $2 OR (status = $3 AND interactions >= $4))
+		ORDER BY interactions DESC, label`
+
+	rows, err := r.db.Query(ctx, query, walletID, models.WalletLabelAccepted, models.WalletLabelSuggested, minInteractions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []*models.WalletLabelSuggestion{}
+	for rows.Next() {
+		label, err := scanWalletLabel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+func (r *walletLabelRepository) SetStatus(ctx context.Context, walletID uuid.UUID, label, status string) (*models.WalletLabelSuggestion, error) {
+	query := `UPDATE wallet_label_suggestions SET status = $3
+		WHERE wallet_id = $1 AND label = $2
+		RETURNING ` + walletLabelColumns
+
+	suggestion, err := scanWalletLabel(r.db.QueryRow(ctx, query, walletID, label, status))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update wallet label: %w", err)
+	}
+	return suggestion, nil
+}
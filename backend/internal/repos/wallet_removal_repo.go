@@ -0,0 +1,251 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WalletRemovalRepository interface {
+	// Create removes one of the user's wallets, hiding it at once, and queues the removal
+	// that clears its data. It returns ErrWalletNotFound when the user has no such wallet.
+	Create(ctx context.Context, removal *models.WalletRemoval) error
+	// GetByID returns one of the user's removals, or nil when there's none with the ID
+	GetByID(ctx context.Context, id, userID uuid.UUID) (*models.WalletRemoval, error)
+	// GetNextActive returns the oldest pending or running removal, or nil when there's none
+	GetNextActive(ctx context.Context) (*models.WalletRemoval, error)
+	// Start marks a pending removal running. It updates removal in place.
+	Start(ctx context.Context, removal *models.WalletRemoval, at time.Time) error
+	// RunStep runs one of the removal's steps, returning the rows it deleted or, for
+	// alerts kept, disabled. Steps can be run again after failing part way.
+	RunStep(ctx context.Context, removal *models.WalletRemoval, step string) (int64, error)
+	// SaveProgress stores the removal's status, counts and last error. It updates removal
+	// in place.
+	SaveProgress(ctx context.Context, removal *models.WalletRemoval) error
+}
+
+type walletRemovalRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletRemovalRepository(db *pgxpool.Pool) WalletRemovalRepository {
+	return &walletRemovalRepository{db: db}
+}
+
+const walletRemovalColumns = `id, wallet_id, user_id, address, chain_id, retention, status, steps_total,
+	steps_done, rows_deleted, attempts, last_error, started_at, completed_at, created_at, updated_at`
+
+// walletAlerts matches the user's alerts on the removed wallet's address, given the
+// wallet ID as $1, owner as $2, address as $3 and chain as $4. Alerts on any chain, with
+// a chainId of 0, are left alone while the user still tracks the address on another one.
+const walletAlerts = `a.user_id = $2
+	AND a.target->>'type' = 'address'
+	AND LOWER(a.target->>'identifier') = LOWER($3)
+	AND COALESCE((a.target->>'chainId')::int, 0) IN (0, $4)
+	AND NOT EXISTS (
+		SELECT 1 FROM wallets w
+		WHERE w.user_id = $2 AND w.id <> $1 AND w.removed_at IS NULL
+		  AND LOWER(w.address) = LOWER($3)
+		  AND COALESCE((a.target->>'chainId')::int, 0) IN (0, w.chain_id))`
+
+// walletRemovalQueries clear the data kept for a wallet, one query per step. Alerts take
+// the arguments of walletAlerts and are deleted by a purge, or disabled by alertsKeptQuery
+// when history is kept; the other steps take the wallet ID as $1.
+var walletRemovalQueries = map[string]string{
+	models.WalletRemovalStepAlerts: `DELETE FROM alerts a WHERE ` + walletAlerts,
+	models.WalletRemovalStepBalances: `
+		WITH history AS (DELETE FROM balance_history WHERE wallet_id = $1),
+		     changes AS (DELETE FROM balance_changes WHERE wallet_id = $1)
+		DELETE FROM balances WHERE wallet_id = $1`,
+	models.WalletRemovalStepAllowances: `DELETE FROM token_allowances WHERE wallet_id = $1`,
+	models.WalletRemovalStepPositions:  `DELETE FROM yield_positions WHERE wallet_id = $1`,
+	models.WalletRemovalStepNFTs:       `DELETE FROM nft_transfers WHERE wallet_id = $1`,
+	models.WalletRemovalStepSnapshots:  `DELETE FROM wallet_holding_snapshots WHERE wallet_id = $1`,
+	models.WalletRemovalStepBackfill:   `DELETE FROM wallet_backfills WHERE wallet_id = $1`,
+	models.WalletRemovalStepLabels:     `DELETE FROM wallet_label_suggestions WHERE wallet_id = $1`,
+	// Transactions other wallets share, with the same address or as a counterparty, stay,
+	// as do those PnL lots passed on refer to. The deleted links are still visible to the
+	// outer statement, hence wallet_id <> $1.
+	models.WalletRemovalStepTransactions: `
+		WITH unlinked AS (
+			DELETE FROM user_transactions WHERE wallet_id = $1
+			RETURNING transaction_id, chain_id
+		)
+		DELETE FROM transactions t
+		USING (SELECT DISTINCT transaction_id, chain_id FROM unlinked) u
+		WHERE t.id = u.transaction_id AND t.chain_id = u.chain_id
+		  AND NOT EXISTS (SELECT 1 FROM user_transactions ut
+		                  WHERE ut.transaction_id = t.id AND ut.chain_id = t.chain_id AND ut.wallet_id <> $1)
+		  AND NOT EXISTS (SELECT 1 FROM pnl_lots l
+		                  WHERE l.transaction_hash = t.hash AND l.chain_id = t.chain_id AND l.wallet_id <> $1)`,
+	// PnL lots are shared by the users tracking the address, so they pass to the next
+	// wallet added for it rather than being deleted while anyone else tracks it
+	models.WalletRemovalStepLots: `
+		WITH heir AS (
+			SELECT h.id FROM wallets h
+			JOIN wallets w ON w.id = $1
+			WHERE h.id <> w.id AND LOWER(h.address) = LOWER(w.address)
+			  AND h.chain_namespace = w.chain_namespace AND h.chain_reference = w.chain_reference
+			ORDER BY h.created_at, h.id
+			LIMIT 1
+		), moved AS (
+			UPDATE pnl_lots SET wallet_id = (SELECT id FROM heir)
+			WHERE wallet_id = $1 AND EXISTS (SELECT 1 FROM heir)
+		)
+		DELETE FROM pnl_lots WHERE wallet_id = $1 AND NOT EXISTS (SELECT 1 FROM heir)`,
+	// What's left, such as valuations and imports, goes with the wallet
+	models.WalletRemovalStepWallet: `DELETE FROM wallets WHERE id = $1 AND removed_at IS NOT NULL`,
+}
+
+// alertsKeptQuery disables the alerts on a wallet removed keeping its history
+const alertsKeptQuery = `UPDATE alerts a SET status = '` + models.AlertStatusDisabled + `'
+	WHERE a.status <> '` + models.AlertStatusDisabled + `' AND ` + walletAlerts
+
+func scanWalletRemoval(row pgx.Row) (*models.WalletRemoval, error) {
+	var r models.WalletRemoval
+	err := row.Scan(
+		&r.ID,
+		&r.WalletID,
+		&r.UserID,
+		&r.Address,
+		&r.ChainID,
+		&r.Retention,
+		&r.Status,
+		&r.StepsTotal,
+		&r.StepsDone,
+		&r.RowsDeleted,
+		&r.Attempts,
+		&r.LastError,
+		&r.StartedAt,
+		&r.CompletedAt,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *walletRemovalRepository) Create(ctx context.Context, removal *models.WalletRemoval) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A removed wallet leaves its group and stops being the primary one straight away
+	err = tx.QueryRow(ctx, `
+		UPDATE wallets SET removed_at = NOW(), is_primary = FALSE, group_id = NULL
+		WHERE id = $1 AND user_id = $2 AND removed_at IS NULL
+		RETURNING address, chain_id`, removal.WalletID, removal.UserID,
+	).Scan(&removal.Address, &removal.ChainID)
+	if err == pgx.ErrNoRows {
+		return ErrWalletNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove wallet: %w", err)
+	}
+
+	query := `
+		INSERT INTO wallet_removals (wallet_id, user_id, address, chain_id, retention, steps_total)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + walletRemovalColumns
+
+	created, err := scanWalletRemoval(tx.QueryRow(ctx, query,
+		removal.WalletID, removal.UserID, removal.Address, removal.ChainID, removal.Retention,
+		len(models.WalletRemovalSteps(removal.Retention)),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create wallet removal: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit wallet removal: %w", err)
+	}
+	*removal = *created
+	return nil
+}
+
+func (r *walletRemovalRepository) GetByID(ctx context.Context, id, userID uuid.UUID) (*models.WalletRemoval, error) {
+	query := `SELECT ` + walletRemovalColumns + ` FROM wallet_removals WHERE id = $1 AND user_id = $2`
+
+	removal, err := scanWalletRemoval(r.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet removal: %w", err)
+	}
+	return removal, nil
+}
+
+func (r *walletRemovalRepository) GetNextActive(ctx context.Context) (*models.WalletRemoval, error) {
+	query := `SELECT ` + walletRemovalColumns + ` FROM wallet_removals
+		WHERE status IN ($1, $2)
+		ORDER BY created_at, id
+		LIMIT 1`
+
+	removal, err := scanWalletRemoval(r.db.QueryRow(ctx, query, models.WalletRemovalPending, models.WalletRemovalRunning))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active wallet removal: %w", err)
+	}
+	return removal, nil
+}
+
+func (r *walletRemovalRepository) Start(ctx context.Context, removal *models.WalletRemoval, at time.Time) error {
+	query := `UPDATE wallet_removals SET status = $2, started_at = $3
+		WHERE id = $1
+		RETURNING ` + walletRemovalColumns
+
+	started, err := scanWalletRemoval(r.db.QueryRow(ctx, query, removal.ID, models.WalletRemovalRunning, at))
+	if err != nil {
+		return fmt.Errorf("failed to start wallet removal: %w", err)
+	}
+	*removal = *started
+	return nil
+}
+
+func (r *walletRemovalRepository) RunStep(ctx context.Context, removal *models.WalletRemoval, step string) (int64, error) {
+	query, ok := walletRemovalQueries[step]
+	if !ok {
+		return 0, fmt.Errorf("unknown wallet removal step %q", step)
+	}
+	args := []interface{}{removal.WalletID}
+	if step == models.WalletRemovalStepAlerts {
+		if removal.Retention == models.WalletRemovalKeepHistory {
+			query = alertsKeptQuery
+		}
+		args = append(args, removal.UserID, removal.Address, removal.ChainID)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove wallet %s: %w", step, err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *walletRemovalRepository) SaveProgress(ctx context.Context, removal *models.WalletRemoval) error {
+	query := `UPDATE wallet_removals SET
+			status = $2, steps_done = $3, rows_deleted = $4, attempts = $5, last_error = $6, completed_at = $7
+		WHERE id = $1
+		RETURNING ` + walletRemovalColumns
+
+	saved, err := scanWalletRemoval(r.db.QueryRow(ctx, query,
+		removal.ID, removal.Status, removal.StepsDone, removal.RowsDeleted, removal.Attempts,
+		removal.LastError, removal.CompletedAt,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to save wallet removal progress: %w", err)
+	}
+	*removal = *saved
+	return nil
+}
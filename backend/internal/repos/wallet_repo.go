@@ -2,12 +2,25 @@ package repos
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrWalletNotFound is returned when no wallet matches. It wraps ErrNotFound.
+var ErrWalletNotFound = fmt.Errorf("wallet %w", ErrNotFound)
+
+// ErrWalletAlreadyTracked is returned when the user already tracks the address on the chain,
+// or is still removing it. Other users tracking it don't count.
+var ErrWalletAlreadyTracked = errors.New("wallet already tracked")
+
 type walletRepository struct {
 	db *pgxpool.Pool
 }
@@ -17,79 +30,239 @@ func NewWalletRepository(db *pgxpool.Pool) WalletRepository {
 	return &walletRepository{db: db}
 }
 
+const walletColumns = `id, user_id, address, chain_id, chain_namespace, chain_reference, label,
+	group_id, visibility, account_type, is_primary, is_testnet, created_at, updated_at`
+
+func scanWallet(row pgx.Row) (*models.Wallet, error) {
+	var wallet models.Wallet
+	var chain models.ChainRef
+	err := row.Scan(
+		&wallet.ID,
+		&wallet.UserID,
+		&wallet.Address,
+		&wallet.ChainID,
+		&chain.Namespace,
+		&chain.Reference,
+		&wallet.Label,
+		&wallet.GroupID,
+		&wallet.Visibility,
+		&wallet.AccountType,
+		&wallet.IsPrimary,
+		&wallet.IsTestnet,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if chain.Namespace != models.ChainNamespaceEVM {
+		wallet.Chain = &chain
+	}
+	return &wallet, nil
+}
+
 func (r *walletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Wallet, error) {
-	// TODO: Implement actual database query
-	// Mock data for now
-	label := "Main Wallet"
-	return []*models.Wallet{
-		{
-			ID:        uuid.New(),
-			UserID:    userID,
-			Address:   "0x1234567890123456789012345678901234567890",
-			ChainID:   1,
-			Label:     &label,
-			IsPrimary: true,
-		},
-		{
-			ID:        uuid.New(),
-			UserID:    userID,
-			Address:   "0x0987654321098765432109876543210987654321",
-			ChainID:   137,
-			Label:     nil,
-			IsPrimary: false,
-		},
-	}, nil
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE user_id = $1 AND removed_at IS NULL ORDER BY is_primary DESC, created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
 }
 
+// GetByAddress returns the first wallet added for the address on the chain. Users tracking
+// the same address share the data kept under that wallet, such as its PnL lots, so a
+// wallet removed keeping its history still counts.
 func (r *walletRepository) GetByAddress(ctx context.Context, address string, chainID int) (*models.Wallet, error) {
-	// TODO: Implement actual database query
-	return &models.Wallet{
-		ID:        uuid.New(),
-		UserID:    uuid.New(),
-		Address:   address,
-		ChainID:   chainID,
-		IsPrimary: false,
-	}, nil
+	query := `SELECT ` + walletColumns + ` FROM wallets
+		WHERE LOWER(address) = LOWER($1) AND chain_namespace = 'eip155' AND chain_id = $2
+		ORDER BY created_at, id
+		LIMIT 1`
+
+	wallet, err := scanWallet(r.db.QueryRow(ctx, query, address, chainID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return wallet, nil
+}
+
+func (r *walletRepository) GetAllByAddresses(ctx context.Context, addresses []string, chainID int) ([]*models.Wallet, error) {
+	query := `SELECT ` + walletColumns + ` FROM wallets
+		WHERE LOWER(address) = ANY($1) AND chain_namespace = 'eip155' AND chain_id = $2 AND removed_at IS NULL
+		ORDER BY created_at, id`
+
+	normalized := make([]string, len(addresses))
+	for i, address := range addresses {
+		normalized[i] = strings.ToLower(address)
+	}
+	rows, err := r.db.Query(ctx, query, normalized, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
 }
 
 func (r *walletRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
-	// TODO: Implement actual database query
-	return &models.Wallet{
-		ID:        id,
-		UserID:    uuid.New(),
-		Address:   "0x1234567890123456789012345678901234567890",
-		ChainID:   1,
-		IsPrimary: true,
-	}, nil
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = $1 AND removed_at IS NULL`
+
+	wallet, err := scanWallet(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	return wallet, nil
 }
 
+// Create adds a wallet. A wallet the user removed keeping its history is restored with
+// that history, once its removal has finished.
 func (r *walletRepository) Create(ctx context.Context, userID uuid.UUID, address string, chainID int, label *string, isPrimary bool) (*models.Wallet, error) {
-	// TODO: Implement actual database insert
-	return &models.Wallet{
-		ID:        uuid.New(),
-		UserID:    userID,
-		Address:   address,
-		ChainID:   chainID,
-		Label:     label,
-		IsPrimary: isPrimary,
-	}, nil
+	query := `
+		INSERT INTO wallets (user_id, address, chain_id, chain_reference, label, is_primary, is_testnet)
+		VALUES ($1, $2, $3, $3::text, $4, $5, $6)
+		ON CONFLICT ON CONSTRAINT wallets_user_address_chain_key DO UPDATE
+			SET removed_at = NULL, label = EXCLUDED.label, is_primary = EXCLUDED.is_primary
+			WHERE wallets.removed_at IS NOT NULL
+			  AND NOT EXISTS (SELECT 1 FROM wallet_removals r
+			                  WHERE r.wallet_id = wallets.id AND r.status IN ('pending', 'running'))
+		RETURNING ` + walletColumns
+
+	wallet, err := scanWallet(r.db.QueryRow(ctx, query, userID, addr.Normalize(address), chainID, label, isPrimary, blockchain.IsTestnet(chainID)))
+	if err == pgx.ErrNoRows {
+		// Tracked already, or still being removed
+		return nil, ErrWalletAlreadyTracked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+	return wallet, nil
 }
 
 func (r *walletRepository) Update(ctx context.Context, id, userID uuid.UUID, label *string) (*models.Wallet, error) {
-	// TODO: Implement actual database update
-	return &models.Wallet{
-		ID:      id,
-		UserID:  userID,
-		Label:   label,
-	}, nil
+	query := `
+		UPDATE wallets SET label = $3
+		WHERE id = $1 AND user_id = $2 AND removed_at IS NULL
+		RETURNING ` + walletColumns
+
+	wallet, err := scanWallet(r.db.QueryRow(ctx, query, id, userID, label))
+	if err == pgx.ErrNoRows {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update wallet: %w", err)
+	}
+	return wallet, nil
 }
 
-func (r *walletRepository) SetPrimary(ctx context.Context, userID, walletID uuid.UUID) error {
-	// TODO: Implement actual database update
+// SetVisibility makes one of the user's wallets private or visible
+func (r *walletRepository) SetVisibility(ctx context.Context, id, userID uuid.UUID, visibility string) (*models.Wallet, error) {
+	query := `
+		UPDATE wallets SET visibility = $3
+		WHERE id = $1 AND user_id = $2 AND removed_at IS NULL
+		RETURNING ` + walletColumns
+
+	wallet, err := scanWallet(r.db.QueryRow(ctx, query, id, userID, visibility))
+	if err == pgx.ErrNoRows {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set wallet visibility: %w", err)
+	}
+	return wallet, nil
+}
+
+func (r *walletRepository) GetUndetectedAccounts(ctx context.Context, limit int) ([]*models.Wallet, error) {
+	query := `SELECT ` + walletColumns + ` FROM wallets
+		WHERE account_type IS NULL AND chain_namespace = $1 AND removed_at IS NULL
+		ORDER BY created_at, id
+		LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, models.ChainNamespaceEVM, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get undetected wallets: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+	return wallets, rows.Err()
+}
+
+// SetAccountType records what the wallet's address holds on chain
+func (r *walletRepository) SetAccountType(ctx context.Context, id uuid.UUID, accountType string) error {
+	result, err := r.db.Exec(ctx, `UPDATE wallets SET account_type = $2 WHERE id = $1`, id, accountType)
+	if err != nil {
+		return fmt.Errorf("failed to set wallet account type: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWalletNotFound
+	}
 	return nil
 }
 
+// SetPrimary makes walletID the user's only primary wallet
+func (r *walletRepository) SetPrimary(ctx context.Context, userID, walletID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM wallets WHERE id = $1 AND user_id = $2 AND removed_at IS NULL)`, walletID, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if !exists {
+		return ErrWalletNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE wallets SET is_primary = (id = $2) WHERE user_id = $1`, userID, walletID); err != nil {
+		return fmt.Errorf("failed to set primary wallet: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
 func (r *walletRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
-	// TODO: Implement actual database delete
+	result, err := r.db.Exec(ctx, `DELETE FROM wallets WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete wallet: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWalletNotFound
+	}
 	return nil
-}
\ No newline at end of file
+}
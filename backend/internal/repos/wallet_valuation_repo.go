@@ -0,0 +1,222 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WalletValuationRepository interface {
+	RecordAll(ctx context.Context) (int64, error)
+	GetPortfolioValuations(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.PortfolioValuation, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+	// RecordHoldings snapshots every mainnet wallet's token balances
+	RecordHoldings(ctx context.Context) (int64, error)
+	// GetLatestHoldingsRun returns when holdings were last snapshotted, or nil if never
+	GetLatestHoldingsRun(ctx context.Context) (*time.Time, error)
+	// GetHoldingsAt returns the wallets' holdings at the latest snapshot taken by at,
+	// with when it was taken; nil if there was none
+	GetHoldingsAt(ctx context.Context, walletIDs []uuid.UUID, at time.Time) (*time.Time, []*models.WalletHolding, error)
+	// GetCurrentHoldings returns the wallets' stored balances valued as a snapshot would
+	GetCurrentHoldings(ctx context.Context, walletIDs []uuid.UUID) ([]*models.WalletHolding, error)
+	DeleteHoldingsOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+type walletValuationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWalletValuationRepository(db *pgxpool.Pool) WalletValuationRepository {
+	return &walletValuationRepository{db: db}
+}
+
+// RecordAll snapshots the USD value of every mainnet wallet. Balances are valued at the
+// token's current price, falling back to the value stored when the balance was synced.
+func (r *walletValuationRepository) RecordAll(ctx context.Context) (int64, error) {
+	query := `
+		INSERT INTO wallet_valuations (wallet_id, user_id, value_usd, recorded_at)
+		SELECT w.id, w.user_id,
+		       COALESCE(SUM(COALESCE(b.balance / POWER(10::numeric, t.decimals) * p.price_usd, b.balance_usd, 0)), 0),
+		       NOW()
+		FROM wallets w
+		LEFT JOIN balances b ON b.wallet_id = w.id AND b.balance > 0
+		LEFT JOIN tokens t ON t.id = b.token_id
+		LEFT JOIN token_prices_latest p ON p.token_id = t.id
+		WHERE NOT w.is_testnet AND w.removed_at IS NULL
+		GROUP BY w.id, w.user_id
+	`
+
+	tag, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record wallet valuations: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetPortfolioValuations returns each user's latest portfolio total with the previous
+// snapshot's total and the total a day earlier. Users without snapshots are left out.
+func (r *walletValuationRepository) GetPortfolioValuations(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.PortfolioValuation, error) {
+	query := `
+		WITH totals AS (
+			SELECT user_id, recorded_at, SUM(value_usd)::float8 AS value_usd
+			FROM wallet_valuations
+			WHERE user_id = ANY($1) AND recorded_at >= NOW() - INTERVAL '48 hours'
+			GROUP BY user_id, recorded_at
+		), ranked AS (
+			SELECT user_id, recorded_at, value_usd,
+			       ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY recorded_at DESC) AS rn
+			FROM totals
+		), latest AS (
+			SELECT user_id, recorded_at, value_usd FROM ranked WHERE rn = 1
+		), day_ago AS (
+			SELECT DISTINCT ON (t.user_id) t.user_id, t.value_usd
+			FROM totals t
+			JOIN latest l ON l.user_id = t.user_id
+			WHERE t.recorded_at <= l.recorded_at - INTERVAL '24 hours'
+			ORDER BY t.user_id, t.recorded_at DESC
+		)
+		SELECT l.user_id, l.value_usd, l.recorded_at, p.value_usd, d.value_usd
+		FROM latest l
+		LEFT JOIN ranked p ON p.user_id = l.user_id AND p.rn = 2
+		LEFT JOIN day_ago d ON d.user_id = l.user_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio valuations: %w", err)
+	}
+	defer rows.Close()
+
+	valuations := make(map[uuid.UUID]*models.PortfolioValuation, len(userIDs))
+	for rows.Next() {
+		var v models.PortfolioValuation
+		if err := rows.Scan(&v.UserID, &v.ValueUSD, &v.RecordedAt, &v.PreviousValueUSD, &v.DayAgoValueUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan portfolio valuation: %w", err)
+		}
+		valuations[v.UserID] = &v
+	}
+
+	return valuations, rows.Err()
+}
+
+// DeleteOlderThan prunes valuations recorded before the given time
+func (r *walletValuationRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM wallet_valuations WHERE recorded_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune wallet valuations: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// holdingsQuery selects wallets' balances with their price and value, on the same basis
+// as RecordAll
+const holdingsQuery = `
+	SELECT b.wallet_id, t.id, t.chain_id, t.address, t.symbol, b.balance,
+	       (b.balance / POWER(10::numeric, t.decimals))::float8,
+	       p.price_usd::float8,
+	       COALESCE(b.balance / POWER(10::numeric, t.decimals) * p.price_usd, b.balance_usd, 0)::float8
+	FROM balances b
+	JOIN wallets w ON w.id = b.wallet_id
+	JOIN tokens t ON t.id = b.token_id
+	LEFT JOIN token_prices_latest p ON p.token_id = t.id
+	WHERE b.balance > 0 AND NOT w.is_testnet`
+
+func (r *walletValuationRepository) RecordHoldings(ctx context.Context) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var recordedAt time.Time
+	if err := tx.QueryRow(ctx, `INSERT INTO holding_snapshot_runs (recorded_at) VALUES (NOW()) RETURNING recorded_at`).Scan(&recordedAt); err != nil {
+		return 0, fmt.Errorf("failed to record holdings snapshot run: %w", err)
+	}
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO wallet_holding_snapshots (wallet_id, token_id, balance, price_usd, value_usd, recorded_at)
+		SELECT h.wallet_id, h.token_id, h.balance, h.price_usd, h.value_usd, $1
+		FROM (`+holdingsQuery+`) AS h (wallet_id, token_id, chain_id, address, symbol, balance, amount, price_usd, value_usd)`,
+		recordedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record wallet holdings: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit wallet holdings: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *walletValuationRepository) GetLatestHoldingsRun(ctx context.Context) (*time.Time, error) {
+	var latest *time.Time
+	if err := r.db.QueryRow(ctx, `SELECT MAX(recorded_at) FROM holding_snapshot_runs`).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest holdings snapshot: %w", err)
+	}
+	return latest, nil
+}
+
+func (r *walletValuationRepository) GetHoldingsAt(ctx context.Context, walletIDs []uuid.UUID, at time.Time) (*time.Time, []*models.WalletHolding, error) {
+	var recordedAt *time.Time
+	if err := r.db.QueryRow(ctx, `SELECT MAX(recorded_at) FROM holding_snapshot_runs WHERE recorded_at <= $1`, at).Scan(&recordedAt); err != nil {
+		return nil, nil, fmt.Errorf("failed to get holdings snapshot: %w", err)
+	}
+	if recordedAt == nil {
+		return nil, nil, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT s.wallet_id, t.chain_id, t.address, t.symbol, s.balance::text,
+		       (s.balance / POWER(10::numeric, t.decimals))::float8, s.price_usd::float8, s.value_usd::float8
+		FROM wallet_holding_snapshots s
+		JOIN tokens t ON t.id = s.token_id
+		WHERE s.wallet_id = ANY($1) AND s.recorded_at = $2`,
+		walletIDs, *recordedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get wallet holdings: %w", err)
+	}
+	defer rows.Close()
+
+	var holdings []*models.WalletHolding
+	for rows.Next() {
+		var h models.WalletHolding
+		if err := rows.Scan(&h.WalletID, &h.ChainID, &h.TokenAddress, &h.Symbol, &h.Balance, &h.Amount, &h.PriceUSD, &h.ValueUSD); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan wallet holding: %w", err)
+		}
+		holdings = append(holdings, &h)
+	}
+	return recordedAt, holdings, rows.Err()
+}
+
+func (r *walletValuationRepository) GetCurrentHoldings(ctx context.Context, walletIDs []uuid.UUID) ([]*models.WalletHolding, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT h.wallet_id, h.chain_id, h.address, h.symbol, h.balance::text, h.amount, h.price_usd, h.value_usd
+		FROM (`+holdingsQuery+` AND b.wallet_id = ANY($1)) AS h (wallet_id, token_id, chain_id, address, symbol, balance, amount, price_usd, value_usd)`,
+		walletIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet holdings: %w", err)
+	}
+	defer rows.Close()
+
+	var holdings []*models.WalletHolding
+	for rows.Next() {
+		var h models.WalletHolding
+		if err := rows.Scan(&h.WalletID, &h.ChainID, &h.TokenAddress, &h.Symbol, &h.Balance, &h.Amount, &h.PriceUSD, &h.ValueUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet holding: %w", err)
+		}
+		holdings = append(holdings, &h)
+	}
+	return holdings, rows.Err()
+}
+
+// DeleteHoldingsOlderThan prunes holdings snapshots taken before the given time
+func (r *walletValuationRepository) DeleteHoldingsOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM holding_snapshot_runs WHERE recorded_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune wallet holdings: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
\ No newline at end of file
@@ -9,6 +9,9 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrWatchlistItemNotFound is returned when no watchlist item matches. It wraps ErrNotFound.
+var ErrWatchlistItemNotFound = fmt.Errorf("watchlist item %w", ErrNotFound)
+
 type WatchlistRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Watchlist, error)
 	Create(ctx context.Context, watchlist *models.Watchlist) error
@@ -88,7 +91,7 @@ func (r *watchlistRepository) Delete(ctx context.Context, id uuid.UUID, userID u
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("watchlist item not found")
+		return ErrWatchlistItemNotFound
 	}
 
 	return nil
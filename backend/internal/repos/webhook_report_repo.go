@@ -0,0 +1,242 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrWebhookReportNameTaken is returned when the user already has a webhook report with the name
+var ErrWebhookReportNameTaken = errors.New("webhook report name already taken")
+
+type WebhookReportRepository interface {
+	Create(ctx context.Context, report *models.WebhookReport) error
+	// GetByID returns nil when there's no report with the ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookReport, error)
+	// GetByUser lists the user's reports by name
+	GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WebhookReport, error)
+	// Update saves the report's settings, next run and failure count
+	Update(ctx context.Context, report *models.WebhookReport) error
+	// Delete reports whether the user had a report with the ID
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	// GetDue lists enabled reports whose next run is at or before now, longest due first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookReport, error)
+	// SaveRun records the outcome of the report's last delivery and its next run
+	SaveRun(ctx context.Context, report *models.WebhookReport) error
+}
+
+type webhookReportRepository struct {
+	db        *pgxpool.Pool
+	encryptor *crypto.Encryptor
+}
+
+// NewWebhookReportRepository stores reports with their URLs and secrets sealed by
+// encryptor; a nil encryptor stores them in plaintext
+func NewWebhookReportRepository(db *pgxpool.Pool, encryptor *crypto.Encryptor) WebhookReportRepository {
+	return &webhookReportRepository{db: db, encryptor: encryptor}
+}
+
+const webhookReportColumns = `id, user_id, name, url, secret, schedule, sections, enabled, next_run_at,
+	last_run_at, last_status, last_error, consecutive_failures, failure_alerted, created_at, updated_at`
+
+func (r *webhookReportRepository) scanWebhookReport(row pgx.Row) (*models.WebhookReport, error) {
+	var w models.WebhookReport
+	err := row.Scan(
+		&w.ID,
+		&w.UserID,
+		&w.Name,
+		&w.URL,
+		&w.Secret,
+		&w.Schedule,
+		&w.Sections,
+		&w.Enabled,
+		&w.NextRunAt,
+		&w.LastRunAt,
+		&w.LastStatus,
+		&w.LastError,
+		&w.ConsecutiveFailures,
+		&w.FailureAlerted,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range []*string{&w.URL, &w.Secret} {
+		if !crypto.IsEncryptedString(*secret) {
+			continue
+		}
+		if r.encryptor == nil {
+			return nil, errNoEncryptor
+		}
+		if *secret, err = r.encryptor.DecryptString(*secret); err != nil {
+			return nil, fmt.Errorf("failed to decrypt webhook report: %w", err)
+		}
+	}
+	return &w, nil
+}
+
+// seal encrypts the report's URL and secret for storage, when encryptor is set
+func (r *webhookReportRepository) seal(report *models.WebhookReport) (string, string, error) {
+	if r.encryptor == nil {
+		return report.URL, report.Secret, nil
+	}
+	url, err := r.encryptor.EncryptString(report.URL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt webhook report URL: %w", err)
+	}
+	secret, err := r.encryptor.EncryptString(report.Secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt webhook report secret: %w", err)
+	}
+	return url, secret, nil
+}
+
+func (r *webhookReportRepository) Create(ctx context.Context, report *models.WebhookReport) error {
+	url, secret, err := r.seal(report)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webhook_reports (user_id, name, url, secret, schedule, sections, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + webhookReportColumns
+
+	saved, err := r.scanWebhookReport(r.db.QueryRow(ctx, query,
+		report.UserID,
+		report.Name,
+		url,
+		secret,
+		report.Schedule,
+		report.Sections,
+		report.Enabled,
+		report.NextRunAt,
+	))
+	if isUniqueViolation(err, "webhook_reports_user_id_name_key") {
+		return ErrWebhookReportNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create webhook report: %w", err)
+	}
+	*report = *saved
+	return nil
+}
+
+func (r *webhookReportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookReport, error) {
+	query := `SELECT ` + webhookReportColumns + ` FROM webhook_reports WHERE id = $1`
+
+	report, err := r.scanWebhookReport(r.db.QueryRow(ctx, query, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook report: %w", err)
+	}
+	return report, nil
+}
+
+func (r *webhookReportRepository) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WebhookReport, error) {
+	query := `
+		SELECT ` + webhookReportColumns + `
+		FROM webhook_reports
+		WHERE user_id = $1
+		ORDER BY name, id`
+
+	return r.query(ctx, query, userID)
+}
+
+func (r *webhookReportRepository) Update(ctx context.Context, report *models.WebhookReport) error {
+	url, secret, err := r.seal(report)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE webhook_reports
+		SET name = $3, url = $4, secret = $5, schedule = $6, sections = $7, enabled = $8,
+		    next_run_at = $9, consecutive_failures = $10, failure_alerted = $11
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + webhookReportColumns
+
+	saved, err := r.scanWebhookReport(r.db.QueryRow(ctx, query,
+		report.ID,
+		report.UserID,
+		report.Name,
+		url,
+		secret,
+		report.Schedule,
+		report.Sections,
+		report.Enabled,
+		report.NextRunAt,
+		report.ConsecutiveFailures,
+		report.FailureAlerted,
+	))
+	if isUniqueViolation(err, "webhook_reports_user_id_name_key") {
+		return ErrWebhookReportNameTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update webhook report: %w", err)
+	}
+	*report = *saved
+	return nil
+}
+
+func (r *webhookReportRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM webhook_reports WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook report: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *webhookReportRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*models.WebhookReport, error) {
+	query := `
+		SELECT ` + webhookReportColumns + `
+		FROM webhook_reports
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at
+		LIMIT $2`
+
+	return r.query(ctx, query, now, limit)
+}
+
+func (r *webhookReportRepository) SaveRun(ctx context.Context, report *models.WebhookReport) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE webhook_reports
+		SET last_run_at = $2, last_status = $3, last_error = $4, consecutive_failures = $5,
+		    failure_alerted = $6, next_run_at = $7
+		WHERE id = $1
+	`, report.ID, report.LastRunAt, report.LastStatus, report.LastError, report.ConsecutiveFailures,
+		report.FailureAlerted, report.NextRunAt)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook report run: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookReportRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookReport, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*models.WebhookReport{}
+	for rows.Next() {
+		report, err := r.scanWebhookReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
@@ -0,0 +1,48 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WebhookVerificationRepository records which webhook URLs each user has verified. URLs
+// are identified by their hash.
+type WebhookVerificationRepository interface {
+	MarkVerified(ctx context.Context, userID uuid.UUID, urlHash string, at time.Time) error
+	IsVerified(ctx context.Context, userID uuid.UUID, urlHash string) (bool, error)
+}
+
+type webhookVerificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookVerificationRepository(db *pgxpool.Pool) WebhookVerificationRepository {
+	return &webhookVerificationRepository{db: db}
+}
+
+func (r *webhookVerificationRepository) MarkVerified(ctx context.Context, userID uuid.UUID, urlHash string, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO webhook_verifications (user_id, url_hash, verified_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, url_hash) DO UPDATE SET verified_at = EXCLUDED.verified_at`,
+		userID, urlHash, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook verified: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookVerificationRepository) IsVerified(ctx context.Context, userID uuid.UUID, urlHash string) (bool, error) {
+	var verified bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM webhook_verifications WHERE user_id = $1 AND url_hash = $2)`,
+		userID, urlHash).Scan(&verified)
+	if err != nil {
+		return false, fmt.Errorf("failed to check webhook verification: %w", err)
+	}
+	return verified, nil
+}
@@ -0,0 +1,205 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrYieldPoolNotFound is returned when a pool, or the successor named for it, doesn't
+// exist. It wraps ErrNotFound.
+var ErrYieldPoolNotFound = fmt.Errorf("yield pool %w", ErrNotFound)
+
+// ErrYieldPoolMigrationCycle is returned when a successor would end up migrating back to
+// the pool it replaces
+var ErrYieldPoolMigrationCycle = errors.New("yield pool migration cycle")
+
+// YieldPoolMigrationRepository maps the pools protocols migrated away from to the pools
+// that replaced them, and carries the positions held in them across
+type YieldPoolMigrationRepository interface {
+	// SetSuccessor deprecates a pool in favour of its successor. Setting it again moves
+	// the pool to another successor.
+	SetSuccessor(ctx context.Context, poolID, successorPoolID string, at time.Time) error
+	// ClearSuccessor undoes a pool's deprecation
+	ClearSuccessor(ctx context.Context, poolID string) error
+	// CarryOver links a new position to the one its wallet held in a pool migrated to the
+	// new position's pool, taking over its entry, rewards and realized PnL and closing
+	// it. It returns the position carried on, or nil when there's none.
+	CarryOver(ctx context.Context, positionID uuid.UUID) (*uuid.UUID, error)
+	// GetPendingNotices lists active positions in deprecated pools whose owners haven't
+	// been told of the migration, oldest deprecation first
+	GetPendingNotices(ctx context.Context, limit int) ([]*models.YieldPoolMigrationNotice, error)
+	// RecordNotice records that the position's owner was told of the migration
+	RecordNotice(ctx context.Context, notice *models.YieldPoolMigrationNotice, emailed bool, at time.Time) error
+}
+
+type yieldPoolMigrationRepository struct {
+	db txDB
+}
+
+func NewYieldPoolMigrationRepository(db *pgxpool.Pool) YieldPoolMigrationRepository {
+	return &yieldPoolMigrationRepository{db: txDB{pool: db}}
+}
+
+// yieldPoolMigrationDepth caps how many migrations are followed looking for a cycle
+const yieldPoolMigrationDepth = 32
+
+func (r *yieldPoolMigrationRepository) SetSuccessor(ctx context.Context, poolID, successorPoolID string, at time.Time) error {
+	if poolID == successorPoolID {
+		return ErrYieldPoolMigrationCycle
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var pools int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM yield_pools WHERE pool_id IN ($1, $2)`,
+		poolID, successorPoolID).Scan(&pools); err != nil {
+		return fmt.Errorf("failed to get yield pools: %w", err)
+	}
+	if pools < 2 {
+		return ErrYieldPoolNotFound
+	}
+
+	// Following the successor's own migrations must not lead back to the pool
+	var cycle bool
+	err = tx.QueryRow(ctx, `
+		WITH RECURSIVE chain (pool_id, depth) AS (
+			SELECT $2::varchar, 1
+			UNION ALL
+			SELECT p.successor_pool_id, c.depth + 1
+			FROM chain c JOIN yield_pools p ON p.pool_id = c.pool_id
+			WHERE p.successor_pool_id IS NOT NULL AND c.depth < $3
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE pool_id = $1)`,
+		poolID, successorPoolID, yieldPoolMigrationDepth,
+	).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("failed to check yield pool migrations: %w", err)
+	}
+	if cycle {
+		return ErrYieldPoolMigrationCycle
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE yield_pools SET successor_pool_id = $2, deprecated_at = COALESCE(deprecated_at, $3)
+		WHERE pool_id = $1`, poolID, successorPoolID, at); err != nil {
+		return fmt.Errorf("failed to set yield pool successor: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit yield pool successor: %w", err)
+	}
+	return nil
+}
+
+func (r *yieldPoolMigrationRepository) ClearSuccessor(ctx context.Context, poolID string) error {
+	result, err := r.db.Exec(ctx, `
+		UPDATE yield_pools SET successor_pool_id = NULL, deprecated_at = NULL
+		WHERE pool_id = $1`, poolID)
+	if err != nil {
+		return fmt.Errorf("failed to clear yield pool successor: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrYieldPoolNotFound
+	}
+	return nil
+}
+
+func (r *yieldPoolMigrationRepository) CarryOver(ctx context.Context, positionID uuid.UUID) (*uuid.UUID, error) {
+	// The wallet's latest position in a pool migrated to this one that nothing carries on
+	// yet; an open one first. Claimed rewards are listed oldest first.
+	var predecessorID uuid.UUID
+	err := r.db.QueryRow(ctx, `
+		WITH predecessor AS (
+			SELECT o.*
+			FROM yield_positions n
+			JOIN yield_pools np ON np.id = n.pool_id
+			JOIN yield_pools op ON op.successor_pool_id = np.pool_id
+			JOIN yield_positions o ON o.pool_id = op.id AND o.wallet_id = n.wallet_id AND o.id <> n.id
+			WHERE n.id = $1 AND n.migrated_from_position_id IS NULL
+			  AND NOT EXISTS (SELECT 1 FROM yield_positions c WHERE c.migrated_from_position_id = o.id)
+			ORDER BY o.is_active DESC, o.entry_time DESC, o.id
+			LIMIT 1
+		), closed AS (
+			UPDATE yield_positions o SET is_active = FALSE, updated_at = NOW()
+			FROM predecessor p
+			WHERE o.id = p.id AND o.is_active
+		)
+		UPDATE yield_positions n SET
+		    migrated_from_position_id = p.id,
+		    entry_time = p.entry_time,
+		    entry_price_usd = COALESCE(p.entry_price_usd, n.entry_price_usd),
+		    entry_block_number = p.entry_block_number,
+		    entry_transaction_hash = p.entry_transaction_hash,
+		    entry_token_prices = COALESCE(p.entry_token_prices, n.entry_token_prices),
+		    claimed_rewards = COALESCE(p.claimed_rewards, '[]'::jsonb) || COALESCE(n.claimed_rewards, '[]'::jsonb),
+		    total_rewards_usd = COALESCE(p.total_rewards_usd, 0) + COALESCE(n.total_rewards_usd, 0),
+		    realized_pnl_usd = COALESCE(p.realized_pnl_usd, 0) + COALESCE(n.realized_pnl_usd, 0),
+		    total_fees_paid_usd = COALESCE(p.total_fees_paid_usd, 0) + COALESCE(n.total_fees_paid_usd, 0),
+		    updated_at = NOW()
+		FROM predecessor p
+		WHERE n.id = $1
+		RETURNING p.id`, positionID,
+	).Scan(&predecessorID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to carry over yield position: %w", err)
+	}
+	return &predecessorID, nil
+}
+
+func (r *yieldPoolMigrationRepository) GetPendingNotices(ctx context.Context, limit int) ([]*models.YieldPoolMigrationNotice, error) {
+	query := `
+		SELECT yp.id, yp.user_id, u.email, op.pool_id, op.pool_name, op.chain,
+		       sp.id, sp.pool_id, sp.pool_name, sp.apy::float8
+		FROM yield_positions yp
+		JOIN yield_pools op ON op.id = yp.pool_id AND op.successor_pool_id IS NOT NULL
+		JOIN yield_pools sp ON sp.pool_id = op.successor_pool_id
+		JOIN users u ON u.id = yp.user_id
+		JOIN wallets w ON w.id = yp.wallet_id AND w.removed_at IS NULL
+		WHERE yp.is_active
+		  AND NOT EXISTS (SELECT 1 FROM yield_pool_migration_notices n
+		                  WHERE n.position_id = yp.id AND n.successor_pool_id = op.successor_pool_id)
+		ORDER BY op.deprecated_at, yp.id
+		LIMIT $1`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get yield pool migration notices: %w", err)
+	}
+	defer rows.Close()
+
+	var notices []*models.YieldPoolMigrationNotice
+	for rows.Next() {
+		var n models.YieldPoolMigrationNotice
+		if err := rows.Scan(&n.PositionID, &n.UserID, &n.Email, &n.PoolID, &n.PoolName, &n.Chain,
+			&n.SuccessorID, &n.SuccessorPoolID, &n.SuccessorName, &n.SuccessorAPY); err != nil {
+			return nil, fmt.Errorf("failed to scan yield pool migration notice: %w", err)
+		}
+		notices = append(notices, &n)
+	}
+	return notices, rows.Err()
+}
+
+func (r *yieldPoolMigrationRepository) RecordNotice(ctx context.Context, notice *models.YieldPoolMigrationNotice, emailed bool, at time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO yield_pool_migration_notices (position_id, successor_pool_id, user_id, emailed, notified_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (position_id, successor_pool_id) DO NOTHING`,
+		notice.PositionID, notice.SuccessorPoolID, notice.UserID, emailed, at)
+	if err != nil {
+		return fmt.Errorf("failed to record yield pool migration notice: %w", err)
+	}
+	return nil
+}
@@ -3,19 +3,22 @@ package repos
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type yieldPoolRepository struct {
-	db *pgxpool.Pool
+	db txDB
 }
 
 // NewYieldPoolRepository creates a new yield pool repository
 func NewYieldPoolRepository(db *pgxpool.Pool) YieldPoolRepository {
-	return &yieldPoolRepository{db: db}
+	return &yieldPoolRepository{db: txDB{pool: db}}
 }
 
 func (r *yieldPoolRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.YieldPool, error) {
@@ -25,7 +28,8 @@ func (r *yieldPoolRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 		       yp.apy_base, yp.apy_reward, yp.fees_apr, yp.il_7d, yp.risk_level,
 		       yp.min_deposit_usd, yp.max_deposit_usd, yp.is_active, yp.stable_coin,
 		       yp.metadata, yp.created_at, yp.updated_at,
-		       p.name as protocol_name, p.logo_uri as protocol_logo_uri
+		       p.name as protocol_name, p.slug as protocol_slug, p.logo_uri as protocol_logo_uri,
+		       yp.successor_pool_id, yp.deprecated_at
 		FROM yield_pools yp
 		LEFT JOIN protocols p ON yp.protocol_id = p.id
 		WHERE yp.id = $1
@@ -33,7 +37,7 @@ func (r *yieldPoolRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 	
 	var pool models.YieldPool
 	var tokenAddressesJSON, metadataJSON []byte
-	var protocolName, protocolLogoURI *string
+	var protocolName, protocolSlug, protocolLogoURI *string
 	
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&pool.ID, &pool.PoolID, &pool.ProtocolID, &pool.PoolName, &pool.ChainID,
@@ -41,10 +45,11 @@ func (r *yieldPoolRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 		&pool.TVLUSD, &pool.APY, &pool.APYBase, &pool.APYReward, &pool.FeesAPR,
 		&pool.IL7D, &pool.RiskLevel, &pool.MinDepositUSD, &pool.MaxDepositUSD,
 		&pool.IsActive, &pool.StableCoin, &metadataJSON, &pool.CreatedAt,
-		&pool.UpdatedAt, &protocolName, &protocolLogoURI,
+		&pool.UpdatedAt, &protocolName, &protocolSlug, &protocolLogoURI,
+		&pool.SuccessorPoolID, &pool.DeprecatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "yield pool")
 	}
 
 	// Parse JSON fields
@@ -65,6 +70,9 @@ func (r *yieldPoolRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 			Name:    *protocolName,
 			LogoURI: protocolLogoURI,
 		}
+		if protocolSlug != nil {
+			pool.Protocol.Slug = *protocolSlug
+		}
 	}
 
 	return &pool, nil
@@ -77,7 +85,8 @@ func (r *yieldPoolRepository) GetByPoolID(ctx context.Context, poolID string) (*
 		       yp.apy_base, yp.apy_reward, yp.fees_apr, yp.il_7d, yp.risk_level,
 		       yp.min_deposit_usd, yp.max_deposit_usd, yp.is_active, yp.stable_coin,
 		       yp.metadata, yp.created_at, yp.updated_at,
-		       p.name as protocol_name, p.logo_uri as protocol_logo_uri
+		       p.name as protocol_name, p.logo_uri as protocol_logo_uri,
+		       yp.successor_pool_id, yp.deprecated_at
 		FROM yield_pools yp
 		LEFT JOIN protocols p ON yp.protocol_id = p.id
 		WHERE yp.pool_id = $1
@@ -94,9 +103,10 @@ func (r *yieldPoolRepository) GetByPoolID(ctx context.Context, poolID string) (*
 		&pool.IL7D, &pool.RiskLevel, &pool.MinDepositUSD, &pool.MaxDepositUSD,
 		&pool.IsActive, &pool.StableCoin, &metadataJSON, &pool.CreatedAt,
 		&pool.UpdatedAt, &protocolName, &protocolLogoURI,
+		&pool.SuccessorPoolID, &pool.DeprecatedAt,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "yield pool")
 	}
 
 	// Parse JSON fields
@@ -125,7 +135,8 @@ func (r *yieldPoolRepository) GetAll(ctx context.Context, filters YieldPoolFilte
 		       yp.apy_base, yp.apy_reward, yp.fees_apr, yp.il_7d, yp.risk_level,
 		       yp.min_deposit_usd, yp.max_deposit_usd, yp.is_active, yp.stable_coin,
 		       yp.metadata, yp.created_at, yp.updated_at,
-		       p.name as protocol_name, p.logo_uri as protocol_logo_uri, p.category as protocol_category
+		       p.name as protocol_name, p.logo_uri as protocol_logo_uri, p.category as protocol_category,
+		       yp.successor_pool_id, yp.deprecated_at
 		FROM yield_pools yp
 		LEFT JOIN protocols p ON yp.protocol_id = p.id
 		WHERE ($1::varchar IS NULL OR yp.chain = $1)
@@ -165,6 +176,7 @@ func (r *yieldPoolRepository) GetAll(ctx context.Context, filters YieldPoolFilte
 			&pool.IL7D, &pool.RiskLevel, &pool.MinDepositUSD, &pool.MaxDepositUSD,
 			&pool.IsActive, &pool.StableCoin, &metadataJSON, &pool.CreatedAt,
 			&pool.UpdatedAt, &protocolName, &protocolLogoURI, &protocolCategory,
+			&pool.SuccessorPoolID, &pool.DeprecatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -213,13 +225,114 @@ func (r *yieldPoolRepository) Count(ctx context.Context, filters YieldPoolFilter
 	return count, err
 }
 
+// yieldPoolSortColumns maps the fields pool searches sort by to their expressions
+var yieldPoolSortColumns = map[string]string{
+	"apy":        "yp.apy",
+	"apy_base":   "yp.apy_base",
+	"apy_reward": "yp.apy_reward",
+	"fees_apr":   "yp.fees_apr",
+	"tvl":        "yp.tvl_usd",
+	"il_7d":      "yp.il_7d",
+	"name":       "yp.pool_name",
+	"symbol":     "yp.symbol",
+	"chain":      "yp.chain",
+	"protocol":   "p.name",
+	"risk":       "CASE yp.risk_level WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 END",
+	"updated":    "yp.updated_at",
+}
+
+// IsYieldPoolSortField reports whether pool searches can sort by field
+func IsYieldPoolSortField(field string) bool {
+	_, ok := yieldPoolSortColumns[field]
+	return ok
+}
+
+func (r *yieldPoolRepository) Search(ctx context.Context, search YieldPoolSearch) ([]*models.YieldPool, int64, error) {
+	var where whereBuilder
+	if q := buildPrefixTSQuery(strings.Fields(search.Text)); q != "" {
+		where.add("yp.search @@ to_tsquery('simple', ?)", q)
+	}
+	if len(search.ChainIDs) > 0 {
+		where.add("yp.chain_id = ANY(?)", search.ChainIDs)
+	}
+	if len(search.RiskLevels) > 0 {
+		where.add("yp.risk_level = ANY(?)", search.RiskLevels)
+	}
+	if len(search.ProtocolSlugs) > 0 {
+		where.add("p.slug = ANY(?)", search.ProtocolSlugs)
+	}
+	if search.MinAPY != nil {
+		where.add("yp.apy >= ?", *search.MinAPY)
+	}
+	if search.MaxAPY != nil {
+		where.add("yp.apy <= ?", *search.MaxAPY)
+	}
+	if search.MinTVL != nil {
+		where.add("yp.tvl_usd >= ?", *search.MinTVL)
+	}
+	if search.MaxTVL != nil {
+		where.add("yp.tvl_usd <= ?", *search.MaxTVL)
+	}
+	if search.StablecoinOnly {
+		where.add("yp.stable_coin")
+	}
+	if search.IsActive != nil {
+		where.add("yp.is_active = ?", *search.IsActive)
+	}
+	if search.CreatedAfter != nil {
+		where.add("yp.created_at > ?", *search.CreatedAfter)
+	}
+
+	from := `
+		FROM yield_pools yp
+		LEFT JOIN protocols p ON yp.protocol_id = p.id
+		` + where.clause()
+
+	var total int64
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*)"+from, where.args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count yield pools: %w", err)
+	}
+
+	sortColumn, ok := yieldPoolSortColumns[search.SortBy]
+	if !ok {
+		sortColumn = yieldPoolSortColumns["apy"]
+	}
+	direction := "DESC"
+	if search.SortAsc {
+		direction = "ASC"
+	}
+
+	query := `
+		SELECT yp.id, yp.pool_id, yp.protocol_id, yp.pool_name, yp.chain_id, yp.chain,
+		       yp.pool_address, yp.symbol, yp.token_addresses, yp.tvl_usd, yp.apy,
+		       yp.apy_base, yp.apy_reward, yp.fees_apr, yp.il_7d, yp.risk_level,
+		       yp.min_deposit_usd, yp.max_deposit_usd, yp.is_active, yp.stable_coin,
+		       yp.metadata, yp.created_at, yp.updated_at,
+		       p.name as protocol_name, p.logo_uri as protocol_logo_uri, p.category as protocol_category,
+		       yp.successor_pool_id, yp.deprecated_at` + from + `
+		ORDER BY ` + sortColumn + ` ` + direction + ` NULLS LAST, yp.id
+		LIMIT ` + where.arg(search.Limit) + ` OFFSET ` + where.arg(search.Offset)
+
+	rows, err := r.db.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search yield pools: %w", err)
+	}
+	defer rows.Close()
+
+	pools, err := r.scanPoolsFromRows(rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan yield pools: %w", err)
+	}
+	return pools, total, nil
+}
+
 func (r *yieldPoolRepository) GetByProtocol(ctx context.Context, protocolID uuid.UUID, activeOnly bool) ([]*models.YieldPool, error) {
 	query := `
 		SELECT id, pool_id, protocol_id, pool_name, chain_id, chain, 
 		       pool_address, symbol, token_addresses, tvl_usd, apy, 
 		       apy_base, apy_reward, fees_apr, il_7d, risk_level,
 		       min_deposit_usd, max_deposit_usd, is_active, stable_coin,
-		       metadata, created_at, updated_at
+		       metadata, created_at, updated_at, successor_pool_id, deprecated_at
 		FROM yield_pools
 		WHERE protocol_id = $1
 		  AND ($2::boolean IS NULL OR is_active = $2)
@@ -247,7 +360,8 @@ func (r *yieldPoolRepository) GetByChain(ctx context.Context, chainID int) ([]*m
 		       yp.apy_base, yp.apy_reward, yp.fees_apr, yp.il_7d, yp.risk_level,
 		       yp.min_deposit_usd, yp.max_deposit_usd, yp.is_active, yp.stable_coin,
 		       yp.metadata, yp.created_at, yp.updated_at,
-		       p.name as protocol_name
+		       p.name as protocol_name, p.slug as protocol_slug,
+		       yp.successor_pool_id, yp.deprecated_at
 		FROM yield_pools yp
 		LEFT JOIN protocols p ON yp.protocol_id = p.id
 		WHERE yp.chain_id = $1
@@ -271,7 +385,7 @@ func (r *yieldPoolRepository) GetTopByTVL(ctx context.Context, limit int) ([]*mo
 		       yp.apy_base, yp.apy_reward, yp.fees_apr, yp.il_7d, yp.risk_level,
 		       yp.min_deposit_usd, yp.max_deposit_usd, yp.is_active, yp.stable_coin,
 		       yp.metadata, yp.created_at, yp.updated_at,
-		       p.name as protocol_name
+		       p.name as protocol_name, yp.successor_pool_id, yp.deprecated_at
 		FROM yield_pools yp
 		LEFT JOIN protocols p ON yp.protocol_id = p.id
 		WHERE yp.is_active = true
@@ -378,8 +492,72 @@ func (r *yieldPoolRepository) Deactivate(ctx context.Context, poolID string) err
 }
 
 // Helper method for scanning pools from rows
-func (r *yieldPoolRepository) scanPoolsFromRows(rows interface{}) ([]*models.YieldPool, error) {
-	// This is a simplified implementation
-	// In a real implementation, you'd properly scan the rows and handle all fields
-	return []*models.YieldPool{}, nil
+// poolColumnCount is the number of yield_pools columns every pool query selects before
+// any joined protocol columns and the migration columns
+const poolColumnCount = 23
+
+// scanPoolsFromRows scans pools, filling in protocol details from whichever joined
+// columns the query selected after the pool columns
+func (r *yieldPoolRepository) scanPoolsFromRows(rows pgx.Rows) ([]*models.YieldPool, error) {
+	var pools []*models.YieldPool
+	for rows.Next() {
+		var pool models.YieldPool
+		var tokenAddressesJSON, metadataJSON []byte
+		var protocolName, protocolSlug, protocolLogoURI, protocolCategory *string
+		joinedColumns := map[string]interface{}{
+			"protocol_name":     &protocolName,
+			"protocol_slug":     &protocolSlug,
+			"protocol_logo_uri": &protocolLogoURI,
+			"protocol_category": &protocolCategory,
+			"successor_pool_id": &pool.SuccessorPoolID,
+			"deprecated_at":     &pool.DeprecatedAt,
+		}
+
+		dest := []interface{}{
+			&pool.ID, &pool.PoolID, &pool.ProtocolID, &pool.PoolName, &pool.ChainID,
+			&pool.Chain, &pool.PoolAddress, &pool.Symbol, &tokenAddressesJSON,
+			&pool.TVLUSD, &pool.APY, &pool.APYBase, &pool.APYReward, &pool.FeesAPR,
+			&pool.IL7D, &pool.RiskLevel, &pool.MinDepositUSD, &pool.MaxDepositUSD,
+			&pool.IsActive, &pool.StableCoin, &metadataJSON, &pool.CreatedAt,
+			&pool.UpdatedAt,
+		}
+		for _, field := range rows.FieldDescriptions()[poolColumnCount:] {
+			target, ok := joinedColumns[field.Name]
+			if !ok {
+				return nil, fmt.Errorf("unexpected pool column %q", field.Name)
+			}
+			dest = append(dest, target)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		// Parse JSON fields
+		if tokenAddressesJSON != nil {
+			if err := json.Unmarshal(tokenAddressesJSON, &pool.TokenAddresses); err != nil {
+				return nil, err
+			}
+		}
+		if metadataJSON != nil {
+			if err := json.Unmarshal(metadataJSON, &pool.Metadata); err != nil {
+				return nil, err
+			}
+		}
+
+		// Set protocol info if available
+		if protocolName != nil {
+			pool.Protocol = &models.Protocol{
+				Name:     *protocolName,
+				LogoURI:  protocolLogoURI,
+				Category: protocolCategory,
+			}
+			if protocolSlug != nil {
+				pool.Protocol.Slug = *protocolSlug
+			}
+		}
+
+		pools = append(pools, &pool)
+	}
+
+	return pools, rows.Err()
 }
\ No newline at end of file
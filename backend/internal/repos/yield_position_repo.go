@@ -3,19 +3,22 @@ package repos
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type yieldPositionRepository struct {
-	db *pgxpool.Pool
+	db txDB
 }
 
 // NewYieldPositionRepository creates a new yield position repository
 func NewYieldPositionRepository(db *pgxpool.Pool) YieldPositionRepository {
-	return &yieldPositionRepository{db: db}
+	return &yieldPositionRepository{db: txDB{pool: db}}
 }
 
 func (r *yieldPositionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.YieldPosition, error) {
@@ -26,7 +29,7 @@ func (r *yieldPositionRepository) GetByID(ctx context.Context, id uuid.UUID) (*m
 		       is_active, last_update_block, last_update_time,
 		       pending_rewards, claimed_rewards, total_rewards_usd,
 		       current_value_usd, unrealized_pnl_usd, realized_pnl_usd, total_fees_paid_usd,
-		       metadata, created_at, updated_at
+		       metadata, created_at, updated_at, migrated_from_position_id
 		FROM yield_positions 
 		WHERE id = $1
 	`
@@ -43,9 +46,10 @@ func (r *yieldPositionRepository) GetByID(ctx context.Context, id uuid.UUID) (*m
 		&pendingRewardsJSON, &claimedRewardsJSON, &position.TotalRewardsUSD,
 		&position.CurrentValueUSD, &position.UnrealizedPnLUSD, &position.RealizedPnLUSD,
 		&position.TotalFeesPaidUSD, &metadataJSON, &position.CreatedAt, &position.UpdatedAt,
+		&position.MigratedFromPositionID,
 	)
 	if err != nil {
-		return nil, err
+		return nil, queryError(err, "yield position")
 	}
 
 	// Parse JSON fields
@@ -89,7 +93,8 @@ func (r *yieldPositionRepository) GetByUser(ctx context.Context, userID uuid.UUI
 		       yp.current_value_usd, yp.unrealized_pnl_usd, yp.realized_pnl_usd, yp.total_fees_paid_usd,
 		       yp.metadata, yp.created_at, yp.updated_at,
 		       pools.pool_name, pools.protocol_id as pool_protocol_id, pools.apy as pool_apy, pools.tvl_usd as pool_tvl_usd,
-		       protocols.name as protocol_name, protocols.logo_uri as protocol_logo_uri
+		       protocols.name as protocol_name, protocols.logo_uri as protocol_logo_uri,
+		       yp.migrated_from_position_id, pools.successor_pool_id, pools.deprecated_at
 		FROM yield_positions yp
 		LEFT JOIN yield_pools pools ON yp.pool_id = pools.id
 		LEFT JOIN protocols ON yp.protocol_id = protocols.id
@@ -114,6 +119,8 @@ func (r *yieldPositionRepository) GetByUser(ctx context.Context, userID uuid.UUI
 		var poolProtocolID *uuid.UUID
 		var poolAPY, poolTVL *float64
 		var protocolName, protocolLogoURI *string
+		var poolSuccessorID *string
+		var poolDeprecatedAt *time.Time
 		
 		err := rows.Scan(
 			&position.ID, &position.UserID, &position.WalletID, &position.PoolID, &position.ProtocolID,
@@ -125,6 +132,7 @@ func (r *yieldPositionRepository) GetByUser(ctx context.Context, userID uuid.UUI
 			&position.CurrentValueUSD, &position.UnrealizedPnLUSD, &position.RealizedPnLUSD,
 			&position.TotalFeesPaidUSD, &metadataJSON, &position.CreatedAt, &position.UpdatedAt,
 			&poolName, &poolProtocolID, &poolAPY, &poolTVL, &protocolName, &protocolLogoURI,
+			&position.MigratedFromPositionID, &poolSuccessorID, &poolDeprecatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -147,9 +155,11 @@ func (r *yieldPositionRepository) GetByUser(ctx context.Context, userID uuid.UUI
 		// Set pool and protocol information if available
 		if poolName != nil {
 			position.Pool = &models.YieldPool{
-				PoolName: *poolName,
-				APY:      poolAPY,
-				TVLUSD:   poolTVL,
+				PoolName:        *poolName,
+				APY:             poolAPY,
+				TVLUSD:          poolTVL,
+				SuccessorPoolID: poolSuccessorID,
+				DeprecatedAt:    poolDeprecatedAt,
 			}
 			if poolProtocolID != nil {
 				position.Pool.ProtocolID = poolProtocolID
@@ -232,7 +242,7 @@ func (r *yieldPositionRepository) GetByPool(ctx context.Context, poolID uuid.UUI
 	}
 	defer rows.Close()
 
-	return r.scanBasicPositionsFromRows(rows)
+	return r.scanPositionsFromRows(rows)
 }
 
 func (r *yieldPositionRepository) GetByProtocol(ctx context.Context, protocolID, userID uuid.UUID, activeOnly bool) ([]*models.YieldPosition, error) {
@@ -330,7 +340,7 @@ func (r *yieldPositionRepository) GetUserPositionsWithPools(ctx context.Context,
 	}
 	defer rows.Close()
 
-	return r.scanEnhancedPositionsFromRows(rows)
+	return r.scanPositionsFromRows(rows)
 }
 
 func (r *yieldPositionRepository) GetTopByValue(ctx context.Context, limit int) ([]*models.YieldPosition, error) {
@@ -366,26 +376,48 @@ func (r *yieldPositionRepository) Create(ctx context.Context, position *models.Y
 	// Serialize JSON fields
 	balanceTokensJSON, _ := json.Marshal(position.BalanceTokens)
 	metadataJSON, _ := json.Marshal(position.Metadata)
+	var entryPricesJSON []byte
+	if len(position.EntryTokenPrices) > 0 {
+		entryPricesJSON, _ = json.Marshal(position.EntryTokenPrices)
+	}
 
+	// Entry token prices not given are the pool tokens' current prices
 	query := `
 		INSERT INTO yield_positions (
 		    user_id, wallet_id, pool_id, protocol_id, position_id,
 		    pool_address, chain_id, balance_raw, balance_usd, balance_tokens,
 		    entry_price_usd, entry_block_number, entry_transaction_hash, entry_time,
-		    current_value_usd, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-		RETURNING id, created_at, updated_at
+		    current_value_usd, metadata, entry_token_prices
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+		    COALESCE($17::jsonb, (
+		        SELECT jsonb_object_agg(LOWER(t.address), tp.price_usd::float8)
+		        FROM yield_pools p
+		        CROSS JOIN LATERAL jsonb_array_elements_text(p.token_addresses) AS a(address)
+		        JOIN tokens t ON LOWER(t.address) = LOWER(a.address) AND t.chain_id = $7
+		        JOIN token_prices_latest tp ON tp.token_id = t.id
+		        WHERE p.id = $3
+		    )))
+		RETURNING id, entry_token_prices, created_at, updated_at
 	`
 	
+	var storedPricesJSON []byte
 	err := r.db.QueryRow(ctx, query,
 		position.UserID, position.WalletID, position.PoolID, position.ProtocolID,
 		position.PositionID, position.PoolAddress, position.ChainID,
 		position.BalanceRaw, position.BalanceUSD, balanceTokensJSON,
 		position.EntryPriceUSD, position.EntryBlockNumber, position.EntryTransactionHash,
-		position.EntryTime, position.CurrentValueUSD, metadataJSON,
-	).Scan(&position.ID, &position.CreatedAt, &position.UpdatedAt)
-	
-	return position, err
+		position.EntryTime, position.CurrentValueUSD, metadataJSON, entryPricesJSON,
+	).Scan(&position.ID, &storedPricesJSON, &position.CreatedAt, &position.UpdatedAt)
+	if err != nil {
+		return position, queryError(err, "yield position")
+	}
+
+	if storedPricesJSON != nil {
+		if err := json.Unmarshal(storedPricesJSON, &position.EntryTokenPrices); err != nil {
+			return position, err
+		}
+	}
+	return position, nil
 }
 
 func (r *yieldPositionRepository) Update(ctx context.Context, position *models.YieldPosition) (*models.YieldPosition, error) {
@@ -487,20 +519,116 @@ func (r *yieldPositionRepository) UpdateAllPnL(ctx context.Context) error {
 
 // Helper methods for scanning rows
 
-func (r *yieldPositionRepository) scanBasicPositionsFromRows(rows interface{}) ([]*models.YieldPosition, error) {
-	// Implement basic position scanning logic
-	// This is a simplified version - you'd need to implement the full scanning logic
-	return []*models.YieldPosition{}, nil
-}
+// positionColumnCount is the number of yield_positions columns every position query
+// selects before any joined columns
+const positionColumnCount = 28
 
-func (r *yieldPositionRepository) scanPositionsFromRows(rows interface{}) ([]*models.YieldPosition, error) {
-	// Implement position scanning with basic pool/protocol info
-	// This is a simplified version - you'd need to implement the full scanning logic
-	return []*models.YieldPosition{}, nil
-}
+// scanPositionsFromRows scans positions, filling in pool, protocol and user details
+// from whichever joined columns the query selected after the position columns
+func (r *yieldPositionRepository) scanPositionsFromRows(rows pgx.Rows) ([]*models.YieldPosition, error) {
+	var positions []*models.YieldPosition
+	for rows.Next() {
+		var position models.YieldPosition
+		var balanceTokensJSON, pendingRewardsJSON, claimedRewardsJSON, metadataJSON []byte
+		var joined struct {
+			poolName, poolIdentifier, poolRiskLevel                       *string
+			poolProtocolID                                                *uuid.UUID
+			poolAPY, poolTVL                                              *float64
+			protocolName, protocolSlug, protocolLogoURI, protocolCategory *string
+			userAddress                                                   *string
+		}
+		joinedColumns := map[string]interface{}{
+			"pool_name":         &joined.poolName,
+			"pool_identifier":   &joined.poolIdentifier,
+			"pool_risk_level":   &joined.poolRiskLevel,
+			"pool_protocol_id":  &joined.poolProtocolID,
+			"pool_apy":          &joined.poolAPY,
+			"pool_tvl_usd":      &joined.poolTVL,
+			"protocol_name":     &joined.protocolName,
+			"protocol_slug":     &joined.protocolSlug,
+			"protocol_logo_uri": &joined.protocolLogoURI,
+			"protocol_category": &joined.protocolCategory,
+			"user_address":      &joined.userAddress,
+			// A position's own column, selected after the joined ones by queries that need it
+			"migrated_from_position_id": &position.MigratedFromPositionID,
+		}
 
-func (r *yieldPositionRepository) scanEnhancedPositionsFromRows(rows interface{}) ([]*models.YieldPosition, error) {
-	// Implement enhanced position scanning with full pool/protocol info
-	// This is a simplified version - you'd need to implement the full scanning logic
-	return []*models.YieldPosition{}, nil
-}
\ No newline at end of file
+		dest := []interface{}{
+			&position.ID, &position.UserID, &position.WalletID, &position.PoolID, &position.ProtocolID,
+			&position.PositionID, &position.PoolAddress, &position.ChainID, &position.BalanceRaw,
+			&position.BalanceUSD, &balanceTokensJSON, &position.EntryPriceUSD,
+			&position.EntryBlockNumber, &position.EntryTransactionHash, &position.EntryTime,
+			&position.IsActive, &position.LastUpdateBlock, &position.LastUpdateTime,
+			&pendingRewardsJSON, &claimedRewardsJSON, &position.TotalRewardsUSD,
+			&position.CurrentValueUSD, &position.UnrealizedPnLUSD, &position.RealizedPnLUSD,
+			&position.TotalFeesPaidUSD, &metadataJSON, &position.CreatedAt, &position.UpdatedAt,
+		}
+		for _, field := range rows.FieldDescriptions()[positionColumnCount:] {
+			target, ok := joinedColumns[field.Name]
+			if !ok {
+				return nil, fmt.Errorf("unexpected position column %q", field.Name)
+			}
+			dest = append(dest, target)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		// Parse JSON fields
+		for _, field := range []struct {
+			data   []byte
+			target interface{}
+		}{
+			{balanceTokensJSON, &position.BalanceTokens},
+			{pendingRewardsJSON, &position.PendingRewards},
+			{claimedRewardsJSON, &position.ClaimedRewards},
+			{metadataJSON, &position.Metadata},
+		} {
+			if field.data != nil {
+				if err := json.Unmarshal(field.data, field.target); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Set pool, protocol and user information if available
+		if joined.poolName != nil {
+			position.Pool = &models.YieldPool{
+				ID:         position.PoolID,
+				PoolName:   *joined.poolName,
+				ProtocolID: joined.poolProtocolID,
+				APY:        joined.poolAPY,
+				TVLUSD:     joined.poolTVL,
+			}
+			if joined.poolIdentifier != nil {
+				position.Pool.PoolID = *joined.poolIdentifier
+			}
+			if joined.poolRiskLevel != nil {
+				position.Pool.RiskLevel = *joined.poolRiskLevel
+			}
+		}
+		if joined.protocolName != nil {
+			position.Protocol = &models.Protocol{
+				Name:     *joined.protocolName,
+				LogoURI:  joined.protocolLogoURI,
+				Category: joined.protocolCategory,
+			}
+			if joined.protocolSlug != nil {
+				position.Protocol.Slug = *joined.protocolSlug
+			}
+		}
+		if joined.userAddress != nil {
+			position.User = &models.User{ID: position.UserID, Address: *joined.userAddress}
+		}
+
+		// Calculate P&L percentage
+		if position.EntryPriceUSD != nil && *position.EntryPriceUSD > 0 && position.UnrealizedPnLUSD != nil {
+			pnlPercentage := (*position.UnrealizedPnLUSD / *position.EntryPriceUSD) * 100
+			position.PnLPercentage = &pnlPercentage
+		}
+
+		positions = append(positions, &position)
+	}
+
+	return positions, rows.Err()
+}
@@ -1,17 +1,31 @@
 package router
 
 import (
+	"context"
+	"expvar"
+	"fmt"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/config"
+	"github.com/defi-dashboard/backend/internal/events"
 	"github.com/defi-dashboard/backend/internal/handlers"
 	"github.com/defi-dashboard/backend/internal/middleware"
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/defi-dashboard/backend/internal/workerrpc"
+	"github.com/defi-dashboard/backend/pkg/analytics"
+	"github.com/defi-dashboard/backend/pkg/billing"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/correlation"
 	"github.com/defi-dashboard/backend/pkg/pnl"
 	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
 	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/secrets"
+	"github.com/defi-dashboard/backend/pkg/storage"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
@@ -23,7 +37,7 @@ import (
 // CustomErrorHandler handles all errors in a consistent format
 func CustomErrorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
-	var response interface{}
+	var response *errors.AppError
 
 	if e, ok := err.(*fiber.Error); ok {
 		code = e.Code
@@ -43,10 +57,10 @@ func CustomErrorHandler(c *fiber.Ctx, err error) error {
 		"request_id", c.Locals("requestid"),
 	)
 
-	return c.Status(code).JSON(response)
+	return handlers.RespondError(c, code, response)
 }
 
-func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
+func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config, secretsManager *secrets.Manager) {
 	// Global middleware
 	app.Use(requestid.New())
 	app.Use(helmet.New())
@@ -63,7 +77,7 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 
 	// Rate limiting
 	app.Use(limiter.New(limiter.Config{
-		Max:        100,
+		Max:        cfg.RateLimitPerMinute,
 		Expiration: 1 * time.Minute,
 		KeyGenerator: func(c *fiber.Ctx) string {
 			return c.Get("x-forwarded-for", c.IP())
@@ -75,8 +89,15 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 		SkipSuccessfulRequests: false,
 	}))
 
-	// Request logging middleware
-	app.Use(middleware.RequestLogger())
+	// Request logging middleware, keeping failed requests for admins when enabled
+	var requestCaptures *middleware.RequestCaptures
+	if cfg.CaptureFailedRequests {
+		requestCaptures = middleware.NewRequestCaptures(cfg.RequestCaptureSize)
+	}
+	app.Use(middleware.RequestLogger(requestCaptures))
+
+	// Compress large responses such as pool lists and transaction histories
+	app.Use(middleware.Compress(cfg.CompressMinSize))
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -92,18 +113,33 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	walletRepo := repos.NewWalletRepository(db)
 	tokenRepo := repos.NewTokenRepository(db)
 	transactionRepo := repos.NewTransactionRepository(db)
+	transactionAnnotationRepo := repos.NewTransactionAnnotationRepository(db)
 	nonceRepo := repos.NewNonceRepository(db)
+	tokenMetadataRepo := repos.NewTokenMetadataRepository(db)
+	derivativePositionRepo := repos.NewDerivativePositionRepository(db)
+	stakingRepo := repos.NewStakingRepository(db)
+	bitcoinRepo := repos.NewBitcoinRepository(db)
 	
 	// Yield repositories
 	protocolRepo := repos.NewProtocolRepository(db)
+	protocolTVLRepo := repos.NewProtocolTVLRepository(db)
 	yieldPoolRepo := repos.NewYieldPoolRepository(db)
 	yieldPositionRepo := repos.NewYieldPositionRepository(db)
 
 	// Initialize services (blockchain services will be created dynamically with user API keys)
 	authService := services.NewAuthService(userRepo, walletRepo, cfg.JWTSecret, cfg.JWTExpiry)
-	siweService := services.NewSIWEService(userRepo, nonceRepo, "localhost") // TODO: Use actual domain from config
-	portfolioService := services.NewPortfolioService(walletRepo, tokenRepo)
-	transactionService := services.NewTransactionService(transactionRepo)
+	siweService := services.NewSIWEServiceWithContractWallets(userRepo, nonceRepo, "localhost", services.PlatformContractSignatures{}) // TODO: Use actual domain from config
+	esploraClient := external.NewEsploraClient(cfg.BitcoinAPIURL)
+	portfolioService := services.NewPortfolioService(walletRepo, tokenRepo, tokenMetadataRepo, derivativePositionRepo, esploraClient)
+	transactionService := services.NewTransactionService(transactionRepo, transactionAnnotationRepo)
+	debtPositionService := services.NewDebtPositionService(walletRepo)
+	vaultService := services.NewVaultService(walletRepo, yieldPoolRepo)
+	protocolPositionService := services.NewProtocolPositionService(walletRepo)
+	rewardLockService := services.NewRewardLockService(walletRepo, repos.NewRewardLockRepository(db))
+	walletBackfillService := services.NewWalletBackfillService(walletRepo, repos.NewWalletBackfillRepository(db))
+	walletService := services.NewWalletService(walletRepo, repos.NewWalletRemovalRepository(db))
+	stakingService := services.NewStakingService(walletRepo, stakingRepo, external.NewBeaconchainClient(cfg.BeaconchainAPIKey))
+	bitcoinService := services.NewBitcoinService(bitcoinRepo, esploraClient)
 	
 	// Initialize bridge and swap services with external API clients
 	bridgeService := services.NewBridgeService(
@@ -114,40 +150,292 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 		cfg.GetZeroXClientConfig(),
 		cfg.GetOneInchClientConfig(),
 	)
+
+	// Platform keys rotate in place when a secrets manager is configured
+	blockchain.SetPlatformAlchemyKey(cfg.AlchemyAPIKey)
+	blockchain.SetPlatformCoinGeckoKey(cfg.CoinGeckoAPIKey)
+	blockchain.SetTokenMetadataStore(tokenRepo)
+	blockchain.SetTestnetMode(cfg.TestnetMode)
+	if finalityThresholds, err := cfg.GetFinalityThresholds(); err != nil {
+		logger.Error("Invalid finality thresholds, using defaults", "error", err)
+	} else {
+		blockchain.SetFinalityThresholds(finalityThresholds)
+	}
+	if batchSizes, err := cfg.GetRPCBatchSizes(); err != nil {
+		logger.Error("Invalid RPC batch sizes, using defaults", "error", err)
+	} else {
+		blockchain.SetRPCBatchSizes(cfg.RPCBatchSize, batchSizes)
+	}
+	if secretsManager != nil {
+		secretsManager.OnChange(config.SecretAlchemyAPIKey, blockchain.SetPlatformAlchemyKey)
+		secretsManager.OnChange(config.SecretCoinGeckoAPIKey, blockchain.SetPlatformCoinGeckoKey)
+		secretsManager.OnChange(config.SecretZeroXAPIKey, swapService.SetZeroXAPIKey)
+		secretsManager.OnChange(config.SecretLiFiAPIKey, bridgeService.SetLiFiAPIKey)
+	}
+
+	// Admin-set provider policies are reloaded in the background so every instance follows them
+	providerPolicyService := services.NewProviderPolicyService(repos.NewProviderPolicyRepository(db))
+	if err := providerPolicyService.Load(context.Background()); err != nil {
+		logger.Error("Failed to load provider policies", "error", err)
+	}
+	go providerPolicyService.Watch(context.Background(), time.Duration(cfg.ProviderPolicyReloadInterval)*time.Second)
+	bridgeService.SetProviderPolicies(providerPolicyService)
+	bridgeService.SetTokenPrices(tokenRepo)
+	bridgeService.SetNativeBalances(services.PlatformNativeBalances{})
+
+	// Scraping restrictions are shared the same way
+	usageMonitorService := services.NewUsageMonitorService(repos.NewUsageFlagRepository(db))
+	if err := usageMonitorService.Load(context.Background()); err != nil {
+		logger.Error("Failed to load usage restrictions", "error", err)
+	}
+	go usageMonitorService.Watch(context.Background(), time.Duration(cfg.ProviderPolicyReloadInterval)*time.Second)
+	swapService.SetProviderPolicies(providerPolicyService)
+	swapService.SetTokenPrices(tokenRepo)
+
+	// Provider calls made for API requests are logged for support lookups by request ID
+	providerCallLog := services.NewProviderCallLog(repos.NewProviderCallRepository(db), models.ProviderCallSourceAPI, cfg.ProviderCallLogAll)
+	if cfg.ProviderCallLog {
+		correlation.Observe(providerCallLog.Observe)
+		go providerCallLog.Run(context.Background())
+	}
+
+	// Admin-set log settings are followed the same way, and reloaded on SIGHUP
+	logSettingsService := services.NewLogSettingsService(repos.NewFeatureFlagRepository(db), cfg.LogLevel, cfg.LogDebugSampleRate)
+	if err := logSettingsService.Load(context.Background()); err != nil {
+		logger.Error("Failed to load log settings", "error", err)
+	}
+	go logSettingsService.Watch(context.Background(), time.Duration(cfg.LogSettingsReloadInterval)*time.Second)
 	
-	yieldService := services.NewYieldService(yieldPoolRepo, yieldPositionRepo, protocolRepo, userRepo)
-	
+	// On-demand price refreshes share the platform CoinGecko key
+	coinGeckoClient := external.NewCoinGeckoClient(cfg.CoinGeckoAPIKey)
+	if secretsManager != nil {
+		secretsManager.OnChange(config.SecretCoinGeckoAPIKey, coinGeckoClient.SetAPIKey)
+	}
+	priceRefreshService := services.NewPriceRefreshService(tokenMetadataRepo, coinGeckoClient)
+
 	// Initialize PnL service
 	pnlRepo := pnl.NewRepository(db)
 	pnlService := pnl.NewService(pnlRepo, walletRepo, tokenRepo)
-	csvExporter := pnl.NewCSVExporter("/tmp") // TODO: Use configurable temp directory
+	csvExporter := pnl.NewCSVExporter("/tmp") // Only streams here; stored exports go through uploadService
+
+	// User-supplied secrets are sealed at rest; without ENCRYPTION_KEY, API key storage
+	// is disabled and alert webhook URLs are stored in plaintext
+	encryptor, err := cfg.GetEncryptor()
+	if err != nil {
+		logger.Error("Failed to initialize encryptor, user API key storage disabled", "error", err)
+		encryptor = nil
+	}
 
 	// Initialize Alert service
-	alertRepo := repos.NewAlertRepository(db)
-	alertService := services.NewAlertService(alertRepo, userRepo)
+	alertRepo := repos.NewAlertRepository(db, encryptor)
+	// Creating an alert completes that onboarding step, announced to the user's clients,
+	// once the user's plan allows it
+	onboardingService := services.NewOnboardingService(repos.NewOnboardingRepository(db), events.NewPGPublisher(db))
+	planRepo := repos.NewPlanRepository(db)
+	quotaService := services.NewQuotaService(planRepo)
+	alertService := quotaService.AlertService(onboardingService.AlertService(services.NewAlertServiceWithWebhookPolicy(alertRepo, userRepo, cfg.GetWebhookPolicy())))
+	webhookVerificationService := services.NewWebhookVerificationService(repos.NewWebhookVerificationRepository(db), cfg.GetWebhookPolicy())
+	webhookVerificationService.SetQuota(quotaService)
+	bitcoinService.SetQuota(quotaService)
+
+	// Anonymous usage events are batched to the analytics sink, if one is configured
+	analyticsSink, err := analytics.NewSink(cfg.GetAnalyticsConfig())
+	if err != nil {
+		logger.Error("Invalid analytics settings, usage analytics disabled", "error", err)
+	}
+	analyticsService := services.NewAnalyticsService(repos.NewAnalyticsRepository(db), analyticsSink, cfg.AnalyticsSalt)
+	bitcoinService.SetAnalytics(analyticsService)
+	notificationRepo := repos.NewNotificationRepository(db)
+	notificationSettingsService := services.NewNotificationSettingsService(notificationRepo)
+
+	// Services writing more than once per operation commit their writes together
+	unitOfWork := repos.NewUnitOfWork(db)
+
+	// New positions can ask for an APY alert on their pool
+	yieldService := services.NewYieldService(yieldPoolRepo, yieldPositionRepo, protocolRepo, protocolTVLRepo, userRepo, alertService)
+	yieldPoolMigrationService := services.NewYieldPoolMigrationService(yieldPoolRepo, repos.NewYieldPoolMigrationRepository(db), nil, cfg.PublicURL)
+	yieldService.SetMigrations(yieldPoolMigrationService)
+	yieldService.SetUnitOfWork(unitOfWork)
 
 	// Initialize Watchlist repository
 	watchlistRepo := repos.NewWatchlistRepository(db)
 
+	// Saved yield and transaction searches
+	savedSearchService := services.NewSavedSearchService(repos.NewSavedSearchRepository(db))
+	walletGroupService := services.NewWalletGroupService(repos.NewWalletGroupRepository(db), walletRepo, portfolioService, pnlService)
+
+	// Initialize BYO provider key service (disabled when ENCRYPTION_KEY is unset)
+	apiKeyRepo := repos.NewUserAPIKeyRepository(db)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, encryptor)
+	exchangeService := services.NewExchangeService(repos.NewExchangeRepository(db), encryptor)
+
+	// Initialize object storage for exports, reports and imports
+	store, err := cfg.NewStorage()
+	if err != nil {
+		logger.Fatal("Failed to initialize storage", "error", err)
+	}
+	uploadService := services.NewUploadService(repos.NewUploadRepository(db), store, cfg.GetUploadRetention(), cfg.GetSignedURLExpiry())
+	uploadService.SetQuota(quotaService)
+
+	// Initialize email through the configured provider
+	emailSender, err := cfg.NewEmailSender()
+	if err != nil {
+		logger.Fatal("Failed to initialize email", "error", err)
+	}
+	emailService := services.NewEmailService(emailSender, repos.NewEmailRepository(db))
+
+	// Initialize statement reports (generated monthly by the worker or on demand)
+	reportService := services.NewReportService(
+		repos.NewReportRepository(db), walletRepo, userRepo, transactionRepo,
+		portfolioService, bitcoinService, exchangeService, pnlService,
+		uploadService, emailService,
+	)
+	transactionImportService := services.NewTransactionImportService(repos.NewTransactionImportRepository(db), walletRepo, uploadService)
+
 	// Initialize Admin repositories
 	featureFlagRepo := repos.NewFeatureFlagRepository(db)
 	systemBannerRepo := repos.NewSystemBannerRepository(db)
 
+	// Custom EVM chains are served through their own RPC endpoints once registered
+	chainService := services.NewChainService(repos.NewCustomChainRepository(db), featureFlagRepo)
+	if err := chainService.Load(context.Background()); err != nil {
+		logger.Error("Failed to load custom chains", "error", err)
+	}
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService, siweService, cfg.JWTSecret, cfg.JWTExpiry)
+	referralService := services.NewReferralService(repos.NewReferralRepository(db), planRepo, userRepo)
+	authHandler.SetReferrals(referralService)
+	referralHandler := handlers.NewReferralHandler(referralService)
 	portfolioHandler := handlers.NewPortfolioHandler(portfolioService)
+	portfolioDiffHandler := handlers.NewPortfolioDiffHandler(services.NewPortfolioDiffService(walletRepo,
+		repos.NewWalletValuationRepository(db), alertRepo, derivativePositionRepo))
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	tokenHandler := handlers.NewTokenHandler(tokenMetadataRepo, priceRefreshService)
+	contractService := services.NewContractService(repos.NewContractRepository(db))
+	tokenHandler.SetHolders(services.NewTokenHolderService(repos.NewTokenHolderRepository(db)))
+	tokenHandler.SetContracts(contractService)
 	bridgeHandler := handlers.NewBridgeHandler(bridgeService)
+	bridgeHandler.SetAnalytics(analyticsService)
 	swapHandler := handlers.NewSwapHandler(swapService)
-	yieldHandler := handlers.NewYieldHandler(yieldService)
-	analyticsHandler := handlers.NewAnalyticsHandler(pnlService, csvExporter)
-	alertHandler := handlers.NewAlertHandler(alertService)
+	swapHandler.SetAnalytics(analyticsService)
+	privacyHandler := handlers.NewPrivacyHandler(analyticsService)
+	// Experiments are feature flags with variants, measured through usage analytics
+	experimentService := services.NewExperimentService(repos.NewExperimentRepository(db), featureFlagRepo, analyticsService)
+	analyticsService.SetExperiments(experimentService)
+	go analyticsService.Run(context.Background())
+	experimentHandler := handlers.NewExperimentHandler(experimentService)
+	yieldHandler := handlers.NewYieldHandler(yieldService, services.NewYieldEstimator(yieldPoolRepo, tokenRepo, swapService, bridgeService))
+	transferHandler := handlers.NewTransferHandler(services.NewTransferPlanner(walletRepo, repos.NewWalletValuationRepository(db), tokenRepo, swapService, bridgeService))
+	yieldPoolMigrationHandler := handlers.NewYieldPoolMigrationHandler(yieldPoolMigrationService)
+	debtPositionHandler := handlers.NewDebtPositionHandler(debtPositionService)
+	vaultHandler := handlers.NewVaultHandler(vaultService)
+	onboardingHandler := handlers.NewOnboardingHandler(onboardingService)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
+	// Checkout needs Stripe's secret key; the webhook only its signing secret
+	var stripeClient services.CheckoutCreator
+	if cfg.StripeSecretKey != "" {
+		stripeClient = billing.NewStripeClient(billing.StripeAPIURL, cfg.StripeSecretKey)
+	}
+	billingService := services.NewBillingService(repos.NewBillingRepository(db), planRepo,
+		featureFlagRepo, userRepo, stripeClient, cfg.GetBillingConfig())
+	billingService.SetUnitOfWork(unitOfWork)
+	billingHandler := handlers.NewBillingHandler(billingService)
+	allowanceService := services.NewAllowanceService(walletRepo, repos.NewAllowanceRepository(db))
+	allowanceService.SetContracts(contractService)
+	allowanceHandler := handlers.NewAllowanceHandler(allowanceService)
+	protocolPositionHandler := handlers.NewProtocolPositionHandler(protocolPositionService)
+	rewardLockHandler := handlers.NewRewardLockHandler(rewardLockService)
+	walletBackfillHandler := handlers.NewWalletBackfillHandler(walletBackfillService)
+	walletLabelHandler := handlers.NewWalletLabelHandler(services.NewWalletLabelService(walletRepo, repos.NewWalletLabelRepository(db)))
+	addressPoisoningHandler := handlers.NewAddressPoisoningHandler(services.NewAddressPoisoningService(walletRepo, repos.NewAddressPoisoningRepository(db)))
+	walletHandler := handlers.NewWalletHandler(walletService)
+	stakingHandler := handlers.NewStakingHandler(stakingService)
+	bitcoinHandler := handlers.NewBitcoinHandler(bitcoinService)
+	exchangeHandler := handlers.NewExchangeHandler(exchangeService)
+	analyticsHandler := handlers.NewAnalyticsHandler(pnlService, csvExporter, uploadService)
+	feeSavingsHandler := handlers.NewFeeSavingsHandler(services.NewFeeSavingsAdvisor(transactionRepo, bridgeService))
+	alertHandler := handlers.NewAlertHandlerWithCoverage(alertService, services.NewAlertCoverageService(repos.NewAlertCoverageRepository(db), alertRepo))
+	alertBacktestHandler := handlers.NewAlertBacktestHandler(services.NewAlertBacktester(repos.NewMetricHistoryRepository(db)))
+	notificationTemplateHandler := handlers.NewNotificationTemplateHandler(services.NewNotificationTemplateService(tokenRepo, walletRepo))
+	alertPackHandler := handlers.NewAlertPackHandler(services.NewAlertPackService(alertService))
+	alertConfigService := services.NewAlertConfigService(alertService, repos.NewAlertConfigRepository(db))
+	alertConfigService.SetUnitOfWork(unitOfWork)
+	alertConfigHandler := handlers.NewAlertConfigHandler(alertConfigService)
+	alertTargetHandler := handlers.NewAlertTargetHandler(services.NewAlertTargetService(alertService, repos.NewAlertTargetRepository(db), cfg.GetAlertTargetGoneAfter()))
+	webhookVerificationHandler := handlers.NewWebhookVerificationHandler(webhookVerificationService)
+	notificationHandler := handlers.NewNotificationHandler(notificationSettingsService)
 	watchlistHandler := handlers.NewWatchlistHandler(watchlistRepo)
+	savedSearchHandler := handlers.NewSavedSearchHandler(savedSearchService)
+	customMetricHandler := handlers.NewCustomMetricHandler(services.NewCustomMetricService(repos.NewCustomMetricRepository(db)))
+	webhookReportHandler := handlers.NewWebhookReportHandler(services.NewWebhookReportService(repos.NewWebhookReportRepository(db, encryptor),
+		yieldPositionRepo, walletRepo, repos.NewWalletValuationRepository(db), userRepo, webhookVerificationService,
+		cfg.GetWebhookPolicy(), emailService))
+	teamHandler := handlers.NewTeamHandler(services.NewTeamService(repos.NewTeamRepository(db), repos.NewTeamActionRepository(db), repos.NewEscalationRepository(db), alertRepo, userRepo))
+	budgetHandler := handlers.NewBudgetHandler(services.NewBudgetService(repos.NewBudgetRepository(db), walletRepo, transactionRepo, pnlService))
+	walletGroupHandler := handlers.NewWalletGroupHandler(walletGroupService)
+	paperTradingHandler := handlers.NewPaperTradingHandler(services.NewPaperTradingService(repos.NewPaperPortfolioRepository(db)))
+	leaderboardHandler := handlers.NewLeaderboardHandler(services.NewLeaderboardService(repos.NewLeaderboardRepository(db), featureFlagRepo))
+	complianceHandler := handlers.NewComplianceHandler(services.NewComplianceService(repos.NewComplianceRepository(db), featureFlagRepo))
+	marketHandler := handlers.NewMarketHandler(services.NewMarketService(repos.NewMarketRepository(db)))
+	feedRepo := repos.NewFeedRepository(db)
+	feedHandler := handlers.NewFeedHandler(services.NewFeedService(feedRepo, protocolRepo))
+	broadcastHandler := handlers.NewBroadcastHandler(services.NewBroadcastService(repos.NewBroadcastRepository(db),
+		systemBannerRepo, protocolRepo, feedRepo, emailService))
+	emailHandler := handlers.NewEmailHandler(emailService, cfg.EmailWebhookSecret)
+	alchemyWebhooks, err := cfg.GetAlchemyWebhooks()
+	if err != nil {
+		logger.Fatal("Invalid Alchemy webhooks", "error", err)
+	}
+	alchemyWebhookHandler := handlers.NewAlchemyWebhookHandler(services.NewAlchemyWebhookService(repos.NewAlchemyWebhookRepository(db), alchemyWebhooks))
+	impersonationService := services.NewImpersonationService(repos.NewAuditRepository(db), userRepo, cfg.JWTSecret)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	usageHandler := handlers.NewUsageHandler(usageMonitorService)
+	balanceRefreshHandler := handlers.NewBalanceRefreshHandler(services.NewBalanceRefreshService(repos.NewBalanceRefreshRepository(db)))
+	secretHandler := handlers.NewSecretHandler(services.NewSecretRotationService(repos.NewSecretRepository(db), encryptor))
 	adminHandler := handlers.NewAdminHandler(userRepo, featureFlagRepo, systemBannerRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	attestationSigner, err := cfg.GetAttestationSigner()
+	if err != nil {
+		logger.Fatal("Invalid attestation signing key", "error", err)
+	}
+	attestationHandler := handlers.NewAttestationHandler(services.NewAttestationService(walletRepo, repos.NewWalletGroupRepository(db), portfolioService, attestationSigner), uploadService)
+	transactionImportHandler := handlers.NewTransactionImportHandler(transactionImportService)
+	chainHandler := handlers.NewChainHandler(chainService)
+	providerPolicyHandler := handlers.NewProviderPolicyHandler(providerPolicyService)
+	logSettingsHandler := handlers.NewLogSettingsHandler(logSettingsService)
+	requestCaptureHandler := handlers.NewRequestCaptureHandler(requestCaptures)
+	providerCallHandler := handlers.NewProviderCallHandler(providerCallLog)
+	statusHandler := handlers.NewStatusHandler(services.NewStatusService(repos.NewSystemStatusRepository(db), repos.NewProviderCallRepository(db)))
+	jobGraph, err := services.NewWorkerJobGraph()
+	if err != nil {
+		logger.Fatal("Invalid worker job graph", "error", err)
+	}
+	jobGraphHandler := handlers.NewJobGraphHandler(services.NewJobGraphService(jobGraph, repos.NewSystemStatusRepository(db)))
 
-	// API routes
+	// On-demand jobs are queued on the worker when its RPC address is configured
+	var workerTasks handlers.WorkerTasks
+	if cfg.WorkerRPCURL != "" {
+		workerTasks = workerrpc.NewClient(cfg.WorkerRPCURL, cfg.WorkerRPCToken)
+	}
+	workerTaskHandler := handlers.NewWorkerTaskHandler(workerTasks, walletRepo, alertService)
+
+	// Realtime events are published with NOTIFY, mostly by the worker, and fanned out to websocket clients
+	hub := events.NewHub()
+	go hub.Listen(context.Background(), db)
+	realtimeHandler := handlers.NewRealtimeHandler(hub, cfg.GetAllowOrigins())
+
+	// API routes. v2 shares every v1 route and only changes the response shapes; old
+	// shapes still served on v1 are marked deprecated, with a Sunset date once set.
+	v1Sunset, err := cfg.GetAPIV1Sunset()
+	if err != nil {
+		logger.Fatal("Invalid API_V1_SUNSET", "error", err)
+	}
 	api := app.Group("/api")
-	v1 := api.Group("/v1")
+	api.Use("/v2", middleware.SharedRoutes(middleware.APIVersion2))
+	v1 := api.Group("/v1", middleware.APIVersion(middleware.APIVersion1, v1Sunset))
 
 	// Auth routes (no auth required)
 	auth := v1.Group("/auth")
@@ -163,28 +451,129 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	// Get current user (protected)
 	auth.Get("/me", middleware.JWTAuthWithUser(cfg.JWTSecret, userRepo), authHandler.GetMe)
 
+	// Realtime websocket (browsers can't set headers on websockets, so the token may come from ?token=)
+	v1.Get("/ws", middleware.TokenFromQuery(), middleware.JWTAuthWithUser(cfg.JWTSecret, userRepo), realtimeHandler.Stream)
+
+	// Locally stored files (no auth required, the URL is signed)
+	if localStore, ok := store.(*storage.LocalStore); ok {
+		v1.Get("/files/*", handlers.NewFileHandler(localStore).ServeFile)
+	}
+
+	// Email provider bounce and complaint webhooks (authenticated by their token)
+	v1.Post("/webhooks/email/:provider", middleware.BodyLimit(cfg.WebhookBodyLimit), emailHandler.HandleWebhook)
+	// Alchemy Notify address activity (authenticated by its signature)
+	v1.Post("/webhooks/alchemy", middleware.BodyLimit(cfg.WebhookBodyLimit), alchemyWebhookHandler.HandleWebhook)
+	// Stripe subscription and invoice events (authenticated by their signature)
+	v1.Post("/webhooks/stripe", middleware.BodyLimit(cfg.WebhookBodyLimit), billingHandler.HandleWebhook)
+
+	// Attestation verification (no auth required, for whoever an attestation is shared with)
+	v1.Get("/attestations/public-key", attestationHandler.GetPublicKey)
+	v1.Post("/attestations/verify", attestationHandler.VerifyAttestation)
+
+	// Subsystem health for the status page (no auth required)
+	v1.Get("/status", statusHandler.GetStatus)
+
+	// Market overview (no auth required, nothing in it depends on a user)
+	market := v1.Group("/market", middleware.ETag(time.Duration(cfg.CacheMaxAgePools)*time.Second))
+	market.Get("/overview", marketHandler.GetOverview)
+	market.Get("/trending", marketHandler.GetTrending)
+	market.Get("/movers", marketHandler.GetMovers)
+	market.Get("/apy-changes", marketHandler.GetAPYChanges)
+
 	// Protected routes
-	protected := v1.Use(middleware.JWTAuthWithUser(cfg.JWTSecret, userRepo))
+	// Admins holding an impersonation token see the user's data read-only, and usage
+	// that looks like scraping is flagged and limited
+	protected := v1.Use(
+		middleware.JWTAuthWithUser(cfg.JWTSecret, userRepo),
+		middleware.Impersonation(impersonationService),
+		middleware.UsageGuard(usageMonitorService),
+	)
 
 	// Portfolio routes
-	portfolio := protected.Group("/portfolio")
+	portfolio := protected.Group("/portfolio", middleware.ProviderKeys(apiKeyService),
+		middleware.ETag(time.Duration(cfg.CacheMaxAgePortfolio)*time.Second))
 	portfolio.Get("/:address/balances", portfolioHandler.GetBalances)
+	portfolio.Get("/:address/chains", portfolioHandler.GetMultiChainBalances)
 	portfolio.Get("/:address/history", portfolioHandler.GetHistory)
+	portfolio.Get("/:address/sectors", portfolioHandler.GetSectorAllocation)
+	portfolio.Get("/:address/diff", portfolioDiffHandler.GetDiff)
+
+	// Token routes
+	tokens := protected.Group("/tokens")
+	tokens.Get("/:id", tokenHandler.GetToken)
+	tokens.Post("/:id/refresh-price", limiter.New(limiter.Config{
+		Max:        10,
+		Expiration: 1 * time.Minute,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return fmt.Sprint(c.Locals("userID"))
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return errors.New("RATE_LIMIT_EXCEEDED", "Too many price refreshes", fiber.StatusTooManyRequests)
+		},
+	}), tokenHandler.RefreshPrice)
 
 	// Transaction routes
-	transactions := protected.Group("/transactions")
+	transactions := protected.Group("/transactions", middleware.ProviderKeys(apiKeyService))
+	transactions.Get("/search", transactionHandler.SearchTransactions)
+	transactions.Post("/import", middleware.BodyLimit(cfg.ImportBodyLimit), transactionImportHandler.UploadImport)
+	transactions.Get("/import/:id", transactionImportHandler.GetImport)
+	transactions.Post("/import/:id/preview", transactionImportHandler.PreviewImport)
+	transactions.Post("/import/:id/commit", transactionImportHandler.CommitImport)
 	transactions.Get("/:address", transactionHandler.GetTransactions)
 	transactions.Get("/:address/approvals", transactionHandler.GetApprovals)
+	transactions.Put("/:hash/annotation", transactionHandler.SetAnnotation)
+	transactions.Delete("/:hash/annotation", transactionHandler.DeleteAnnotation)
 	transactions.Delete("/:address/approvals/:token", transactionHandler.RevokeApproval)
 
+	// Allowance routes
+	allowances := protected.Group("/allowances", middleware.ProviderKeys(apiKeyService))
+	allowances.Get("/verify", allowanceHandler.VerifyAllowances)
+
+	// Onboarding routes
+	onboarding := protected.Group("/onboarding")
+	onboarding.Get("/", onboardingHandler.GetProgress)
+
+	// Plan quota routes
+	protected.Get("/quota", quotaHandler.GetQuota)
+
+	// Referral routes
+	referrals := protected.Group("/referrals")
+	referrals.Get("/", referralHandler.GetReferrals)
+	referrals.Post("/claim", referralHandler.ClaimReferral)
+
+	// Usage analytics opt-out
+	privacy := protected.Group("/privacy")
+	privacy.Get("/analytics", privacyHandler.GetAnalytics)
+	privacy.Put("/analytics", privacyHandler.UpdateAnalytics)
+
+	// Experiment variants; asking for one exposes the user to it
+	protected.Get("/experiments/:name", experimentHandler.GetVariant)
+
+	// Billing routes (behind the billing feature flag)
+	billingGroup := protected.Group("/billing")
+	billingGroup.Get("/", billingHandler.GetBilling)
+	billingGroup.Post("/checkout", billingHandler.CreateCheckout)
+	billingGroup.Get("/invoices", billingHandler.ListInvoices)
+
+	// Transfer routes
+	transfers := protected.Group("/transfers")
+	transfers.Post("/plan", middleware.ProviderKeys(apiKeyService), transferHandler.PlanTransfer)
+
 	// Yield routes
 	yield := protected.Group("/yield")
 	
 	// Pool endpoints
-	yield.Get("/pools", yieldHandler.GetYieldPools)
-	yield.Get("/pools/top", yieldHandler.GetTopYieldPools)
-	yield.Get("/pools/protocol/:slug", yieldHandler.GetYieldPoolsByProtocol)
-	yield.Get("/pools/chain/:chainId", yieldHandler.GetYieldPoolsByChain)
+	poolsETag := middleware.ETag(time.Duration(cfg.CacheMaxAgePools) * time.Second)
+	yield.Get("/pools", poolsETag, yieldHandler.GetYieldPools)
+	yield.Get("/pools/search", poolsETag, yieldHandler.SearchYieldPools)
+	yield.Get("/pools/top", poolsETag, yieldHandler.GetTopYieldPools)
+	yield.Get("/pools/protocol/:slug", poolsETag, yieldHandler.GetYieldPoolsByProtocol)
+	yield.Get("/pools/chain/:chainId", poolsETag, yieldHandler.GetYieldPoolsByChain)
+	yield.Get("/pools/:id", poolsETag, yieldPoolMigrationHandler.GetYieldPool)
+	yield.Post("/pools/:id/estimate", middleware.ProviderKeys(apiKeyService), yieldHandler.EstimateYieldPool)
+	yield.Post("/pools/:id/transactions", middleware.ProviderKeys(apiKeyService), yieldHandler.BuildPositionTransactions)
+	yield.Get("/pools/:id/rewards", middleware.ProviderKeys(apiKeyService), yieldHandler.GetYieldPoolRewards)
+	yield.Get("/vaults/:chainId/:address", middleware.ProviderKeys(apiKeyService), vaultHandler.GetVault)
 	
 	// Position endpoints
 	yield.Get("/positions/:address", yieldHandler.GetYieldPositions)
@@ -197,6 +586,44 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	yield.Post("/positions/:address", yieldHandler.CreatePosition)
 	yield.Put("/positions/:positionId", yieldHandler.UpdatePosition)
 
+	// Protocol routes
+	protocols := protected.Group("/protocols")
+	protocols.Get("/:slug/tvl", yieldHandler.GetProtocolTVL)
+
+	// Lending and staking position routes
+	positions := protected.Group("/positions", middleware.ProviderKeys(apiKeyService))
+	positions.Get("/debt", debtPositionHandler.GetDebtPositions)
+	positions.Get("/vaults", vaultHandler.GetVaultPositions)
+	positions.Get("/protocols", protocolPositionHandler.GetPositions)
+	positions.Get("/protocols/adapters", protocolPositionHandler.GetAdapters)
+	positions.Post("/protocols/:protocol/transactions", protocolPositionHandler.BuildTransactions)
+	positions.Get("/locks", rewardLockHandler.GetLocks)
+	positions.Post("/locks", rewardLockHandler.CreateVestingSchedule)
+	positions.Post("/locks/sync", rewardLockHandler.SyncLocks)
+	positions.Get("/locks/calendar", rewardLockHandler.GetCalendar)
+	positions.Delete("/locks/:id", rewardLockHandler.DeleteLock)
+	positions.Get("/staking", stakingHandler.GetStakingPositions)
+	positions.Post("/staking/validators", stakingHandler.AddValidator)
+	positions.Delete("/staking/validators/:index", stakingHandler.RemoveValidator)
+
+	// Bitcoin routes (read-only addresses and xpubs)
+	bitcoin := protected.Group("/bitcoin", middleware.ProviderKeys(apiKeyService))
+	bitcoin.Get("/accounts", bitcoinHandler.GetAccounts)
+	bitcoin.Post("/accounts", bitcoinHandler.AddAccount)
+	bitcoin.Delete("/accounts/:id", bitcoinHandler.RemoveAccount)
+	bitcoin.Get("/portfolio", bitcoinHandler.GetPortfolio)
+	bitcoin.Get("/transactions", bitcoinHandler.GetTransactions)
+	bitcoin.Get("/pnl", bitcoinHandler.GetPnL)
+
+	// Exchange account routes (read-only API keys)
+	exchanges := protected.Group("/exchanges", middleware.ProviderKeys(apiKeyService))
+	exchanges.Get("/accounts", exchangeHandler.GetAccounts)
+	exchanges.Post("/accounts", exchangeHandler.AddAccount)
+	exchanges.Delete("/accounts/:id", exchangeHandler.RemoveAccount)
+	exchanges.Get("/portfolio", exchangeHandler.GetPortfolio)
+	exchanges.Get("/trades", exchangeHandler.GetTrades)
+	exchanges.Get("/pnl/:asset", exchangeHandler.GetPnL)
+
 	// Bridge routes
 	bridge := protected.Group("/bridge")
 	bridge.Post("/routes", bridgeHandler.GetBridgeRoutes)
@@ -206,18 +633,87 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	swap := protected.Group("/swap")
 	swap.Post("/quote", swapHandler.GetSwapQuote)
 	swap.Post("/execute", swapHandler.ExecuteSwap)
+	swap.Post("/price-impact", middleware.ProviderKeys(apiKeyService), swapHandler.EstimatePriceImpact)
 
 
 	// Alert routes (protected)
 	alerts := protected.Group("/alerts")
-	alerts.Get("/", alertHandler.GetAlerts)
+	alerts.Get("/", middleware.ETag(time.Duration(cfg.CacheMaxAgeAlerts)*time.Second), alertHandler.GetAlerts)
 	alerts.Post("/", alertHandler.CreateAlert)
 	alerts.Get("/history", alertHandler.GetAlertHistory)
+	alerts.Get("/stats", alertHandler.GetAlertStats)
+	alerts.Post("/backtest", alertBacktestHandler.Backtest)
+	alerts.Get("/templates", notificationTemplateHandler.GetTemplates)
+	alerts.Post("/templates/preview", notificationTemplateHandler.PreviewTemplate)
+	alerts.Post("/export", alertPackHandler.ExportAlerts)
+	alerts.Post("/import", alertPackHandler.ImportAlerts)
+	alerts.Get("/config", alertConfigHandler.GetConfig)
+	alerts.Put("/config", alertConfigHandler.ApplyConfig)
+	// Webhook URLs answer a challenge before alerts are delivered to them
+	alerts.Post("/webhooks/verify", webhookVerificationHandler.VerifyWebhook)
 	alerts.Get("/:alertId", alertHandler.GetAlert)
+	alerts.Get("/:alertId/export", alertPackHandler.ExportAlert)
+	alerts.Get("/:alertId/successors", alertTargetHandler.GetSuccessors)
+	alerts.Post("/:alertId/repoint", alertTargetHandler.Repoint)
 	alerts.Patch("/:alertId", alertHandler.UpdateAlert)
 	alerts.Patch("/:alertId/pause", alertHandler.PauseAlert)
 	alerts.Patch("/:alertId/activate", alertHandler.ActivateAlert)
+	alerts.Patch("/:alertId/mute", alertHandler.MuteAlert)
+	alerts.Put("/:alertId/escalation-policy", teamHandler.SetAlertPolicy)
 	alerts.Delete("/:alertId", alertHandler.DeleteAlert)
+	alerts.Post("/:alertId/evaluate", workerTaskHandler.EvaluateAlert)
+
+	// Wallet routes
+	wallets := protected.Group("/wallets")
+	wallets.Get("/removals/:id", walletHandler.GetWalletRemoval)
+	wallets.Delete("/:walletId", walletHandler.RemoveWallet)
+	wallets.Post("/:walletId/sync", workerTaskHandler.SyncWallet)
+	wallets.Get("/:walletId/sync-status", walletBackfillHandler.GetSyncStatus)
+	wallets.Get("/:walletId/label-suggestions", walletLabelHandler.GetLabelSuggestions)
+	wallets.Put("/:walletId/label-suggestions", walletLabelHandler.UpdateLabelSuggestion)
+	wallets.Get("/:walletId/poisoned-counterparties", addressPoisoningHandler.GetPoisonedCounterparties)
+	wallets.Put("/:walletId/visibility", walletHandler.UpdateVisibility)
+	wallets.Get("/:address/protocols", transactionHandler.GetProtocolInteractions)
+
+	// Wallet group routes (protected)
+	walletGroups := protected.Group("/wallet-groups")
+	walletGroups.Get("/", walletGroupHandler.GetWalletGroups)
+	walletGroups.Post("/", walletGroupHandler.CreateWalletGroup)
+	walletGroups.Get("/:id", walletGroupHandler.GetWalletGroup)
+	walletGroups.Put("/:id", walletGroupHandler.UpdateWalletGroup)
+	walletGroups.Delete("/:id", walletGroupHandler.DeleteWalletGroup)
+	walletGroups.Get("/:id/portfolio", middleware.ProviderKeys(apiKeyService), walletGroupHandler.GetWalletGroupPortfolio)
+	walletGroups.Get("/:id/pnl", walletGroupHandler.GetWalletGroupPnL)
+
+	// Paper trading routes (simulated portfolios at live prices)
+	paper := protected.Group("/paper/portfolios")
+	paper.Get("/", paperTradingHandler.GetPortfolios)
+	paper.Post("/", paperTradingHandler.CreatePortfolio)
+	paper.Get("/:id", middleware.ProviderKeys(apiKeyService), paperTradingHandler.GetPortfolio)
+	paper.Delete("/:id", paperTradingHandler.DeletePortfolio)
+	paper.Get("/:id/trades", paperTradingHandler.GetTrades)
+	paper.Post("/:id/trades", middleware.ProviderKeys(apiKeyService), paperTradingHandler.Trade)
+
+	// Leaderboard routes (opt-in, anonymized) and platform stats; admins turn them on
+	leaderboards := protected.Group("/leaderboards")
+	leaderboards.Get("/participation", leaderboardHandler.GetParticipation)
+	leaderboards.Put("/participation", leaderboardHandler.UpdateParticipation)
+	leaderboards.Get("/:board", leaderboardHandler.GetLeaderboard)
+	protected.Get("/stats/platform", leaderboardHandler.GetPlatformStats)
+
+	// Counterparty screening against sanctions and mixer lists; admins turn it on
+	protected.Get("/compliance/exposure", complianceHandler.GetExposure)
+
+	// News feed of protocol blogs and governance forums
+	protected.Get("/feed", feedHandler.GetFeed)
+
+	// Banners for everyone and those of the broadcasts the user received
+	protected.Get("/banners", broadcastHandler.GetBanners)
+
+	// Notification settings routes (protected)
+	notifications := protected.Group("/notifications")
+	notifications.Get("/settings", notificationHandler.GetSettings)
+	notifications.Put("/settings", notificationHandler.UpdateSettings)
 
 	// Watchlist routes (protected)
 	watchlist := protected.Group("/watchlist")
@@ -225,18 +721,102 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	watchlist.Post("/", watchlistHandler.CreateWatchlistItem)
 	watchlist.Delete("/:id", watchlistHandler.DeleteWatchlistItem)
 
+	// Saved search routes (protected)
+	savedSearches := protected.Group("/saved-searches")
+	savedSearches.Get("/", savedSearchHandler.GetSavedSearches)
+	savedSearches.Post("/", savedSearchHandler.CreateSavedSearch)
+	savedSearches.Get("/shared/:token", savedSearchHandler.GetSharedSavedSearch)
+	savedSearches.Get("/:id", savedSearchHandler.GetSavedSearch)
+	savedSearches.Put("/:id", savedSearchHandler.UpdateSavedSearch)
+	savedSearches.Delete("/:id", savedSearchHandler.DeleteSavedSearch)
+
+	// Custom metrics over the user's portfolio and the dashboard widgets showing them
+	metrics := protected.Group("/metrics")
+	metrics.Get("/", customMetricHandler.GetCustomMetrics)
+	metrics.Post("/", customMetricHandler.CreateCustomMetric)
+	metrics.Get("/variables", customMetricHandler.GetMetricVariables)
+	metrics.Get("/:id", customMetricHandler.GetCustomMetric)
+	metrics.Put("/:id", customMetricHandler.UpdateCustomMetric)
+	metrics.Delete("/:id", customMetricHandler.DeleteCustomMetric)
+	protected.Get("/widgets", customMetricHandler.GetWidgets)
+
+	// Portfolio reports posted to the user's endpoint on a schedule
+	webhookReports := protected.Group("/webhook-reports")
+	webhookReports.Get("/", webhookReportHandler.GetWebhookReports)
+	webhookReports.Post("/", webhookReportHandler.CreateWebhookReport)
+	webhookReports.Get("/:id", webhookReportHandler.GetWebhookReport)
+	webhookReports.Put("/:id", webhookReportHandler.UpdateWebhookReport)
+	webhookReports.Delete("/:id", webhookReportHandler.DeleteWebhookReport)
+	webhookReports.Post("/:id/test", webhookReportHandler.TestWebhookReport)
+
+	// Monthly spending budgets (protected)
+	budgets := protected.Group("/budgets")
+	budgets.Get("/", budgetHandler.GetBudgets)
+	budgets.Put("/:metric", budgetHandler.SetBudget)
+	budgets.Delete("/:metric", budgetHandler.DeleteBudget)
+
+	// Teams with shared escalation policies and on-call schedules (protected)
+	teams := protected.Group("/teams")
+	teams.Get("/", teamHandler.GetTeams)
+	teams.Post("/", teamHandler.CreateTeam)
+	teams.Get("/:teamId/members", teamHandler.GetMembers)
+	teams.Post("/:teamId/members", teamHandler.AddMember)
+	teams.Delete("/:teamId/members/:userId", teamHandler.RemoveMember)
+	teams.Get("/:teamId/escalation-policies", teamHandler.GetPolicies)
+	teams.Post("/:teamId/escalation-policies", teamHandler.CreatePolicy)
+	teams.Put("/:teamId/escalation-policies/:policyId", teamHandler.UpdatePolicy)
+	teams.Delete("/:teamId/escalation-policies/:policyId", teamHandler.DeletePolicy)
+	teams.Get("/:teamId/on-call", teamHandler.GetShifts)
+	teams.Get("/:teamId/on-call/current", teamHandler.GetOnCall)
+	teams.Post("/:teamId/on-call", teamHandler.CreateShift)
+	teams.Delete("/:teamId/on-call/:shiftId", teamHandler.DeleteShift)
+	teams.Get("/:teamId/escalations", teamHandler.GetEscalations)
+	teams.Post("/:teamId/escalations/:escalationId/ack", teamHandler.AcknowledgeEscalation)
+	teams.Get("/:teamId/pending-actions", teamHandler.GetPendingActions)
+	teams.Post("/:teamId/pending-actions/:actionId/approve", teamHandler.ApproveAction)
+	teams.Post("/:teamId/pending-actions/:actionId/reject", teamHandler.RejectAction)
+
+	// Provider API key routes (protected)
+	apiKeys := protected.Group("/api-keys")
+	apiKeys.Get("/", apiKeyHandler.GetAPIKeys)
+	apiKeys.Put("/:provider", apiKeyHandler.SetAPIKey)
+	apiKeys.Delete("/:provider", apiKeyHandler.DeleteAPIKey)
+
 	// Analytics routes (protected)
 	analytics := protected.Group("/analytics")
 	analytics.Get("/pnl/:address", analyticsHandler.GetPnL)
 	analytics.Get("/export", analyticsHandler.ExportPnL)
-	analytics.Get("/download", analyticsHandler.DownloadFile)
 	analytics.Get("/summary/:address", analyticsHandler.GetPnLSummary)
+	analytics.Get("/nft-pnl/:address", analyticsHandler.GetNFTPnL)
+	analytics.Get("/income/:address", analyticsHandler.GetIncome)
+	analytics.Get("/fee-savings/:address", middleware.ProviderKeys(apiKeyService), feeSavingsHandler.GetFeeSavings)
+
+	// Portfolio statement routes (protected)
+	reports := protected.Group("/reports")
+	reports.Get("/preferences", reportHandler.GetPreferences)
+	reports.Put("/preferences", reportHandler.UpdatePreferences)
+	reports.Get("/statements", reportHandler.GetStatements)
+	reports.Post("/statements", reportHandler.GenerateStatement)
+	reports.Get("/statements/:id/download", reportHandler.DownloadStatement)
+
+	// Signed portfolio attestations (protected)
+	protected.Post("/attestations", middleware.ProviderKeys(apiKeyService), attestationHandler.CreateAttestation)
+
+	// Upload routes (exports, statements and imports kept in object storage)
+	uploads := protected.Group("/uploads")
+	uploads.Get("/:id", uploadHandler.GetUpload)
+
+	// Custom chain routes (protected; registration also needs the user_custom_chains flag)
+	chains := protected.Group("/chains")
+	chains.Get("/custom", chainHandler.GetCustomChains)
+	chains.Post("/custom", chainHandler.RegisterCustomChain)
 
 	// Admin routes (protected + admin only)
 	admin := protected.Group("/admin", middleware.AdminAuth())
 	
 	// User management
 	admin.Get("/users", adminHandler.GetUsers)
+	admin.Put("/users/:userId/plan", quotaHandler.SetUserPlan)
 	
 	// Error logs (if available)
 	admin.Get("/errors", adminHandler.GetErrors)
@@ -244,6 +824,10 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	// Feature flags
 	admin.Get("/feature-flags", adminHandler.GetFeatureFlags)
 	admin.Post("/feature-flags", adminHandler.CreateFeatureFlag)
+	admin.Get("/experiments/:name/results", experimentHandler.GetResults)
+	
+	// Worker jobs, their dependencies and latest runs
+	admin.Get("/jobs", jobGraphHandler.GetJobGraph)
 	
 	// System banners
 	admin.Get("/banners", adminHandler.GetSystemBanners)
@@ -251,6 +835,77 @@ func SetupRoutes(app *fiber.App, db *pgxpool.Pool, cfg *config.Config) {
 	admin.Put("/banners/:id", adminHandler.UpdateSystemBanner)
 	admin.Delete("/banners/:id", adminHandler.DeleteSystemBanner)
 
+	// One-off messages to the holders of a token or protocol, as banners and emails
+	admin.Get("/broadcasts", broadcastHandler.GetBroadcasts)
+	admin.Post("/broadcasts", broadcastHandler.SendBroadcast)
+
+	// Runtime metrics, including database query timings per repo method
+	admin.Get("/metrics", adaptor.HTTPHandler(expvar.Handler()))
+
+	// Runtime log level and debug sampling
+	admin.Get("/log-settings", logSettingsHandler.GetLogSettings)
+	admin.Put("/log-settings", logSettingsHandler.UpdateLogSettings)
+	admin.Delete("/log-settings", logSettingsHandler.ResetLogSettings)
+
+	// Failed requests kept by this instance, with bodies redacted
+	admin.Get("/request-captures", requestCaptureHandler.GetRequestCaptures)
+	admin.Get("/request-captures/:id", requestCaptureHandler.GetRequestCapture)
+
+	// Upstream provider calls, by the request ID that made them
+	admin.Get("/provider-calls", providerCallHandler.GetProviderCalls)
+
+	// Custom chains
+	admin.Post("/chains", chainHandler.AdminRegisterCustomChain)
+	admin.Delete("/chains/:chainId", chainHandler.DeleteCustomChain)
+
+	// Bridge and swap provider policies
+	admin.Get("/provider-policies", providerPolicyHandler.GetProviderPolicies)
+	admin.Put("/provider-policies", providerPolicyHandler.UpsertProviderPolicy)
+	admin.Delete("/provider-policies/:id", providerPolicyHandler.DeleteProviderPolicy)
+
+	// Leaderboard participants
+	admin.Get("/leaderboards/participants", leaderboardHandler.GetParticipants)
+	admin.Put("/leaderboards/participants/:userId", leaderboardHandler.UpdateParticipantVisibility)
+
+	// News feed sources
+	admin.Get("/feed-sources", feedHandler.GetFeedSources)
+	admin.Post("/feed-sources", feedHandler.CreateFeedSource)
+	admin.Delete("/feed-sources/:id", feedHandler.DeleteFeedSource)
+
+	// Yield pools migrated to successors
+	admin.Put("/yield-pools/:poolId/successor", yieldPoolMigrationHandler.SetSuccessor)
+	admin.Delete("/yield-pools/:poolId/successor", yieldPoolMigrationHandler.ClearSuccessor)
+
+	// Email suppression list and delivery log
+	admin.Get("/email/suppressions", emailHandler.GetSuppressions)
+	admin.Post("/email/suppressions", emailHandler.CreateSuppression)
+	admin.Delete("/email/suppressions/:email", emailHandler.DeleteSuppression)
+	admin.Get("/email/deliveries", emailHandler.GetDeliveries)
+
+	// Read-only impersonation for support, and the audit log recording it
+	admin.Post("/impersonations", impersonationHandler.StartImpersonation)
+	admin.Delete("/impersonations/:id", impersonationHandler.EndImpersonation)
+	admin.Get("/audit-log", impersonationHandler.GetAuditLog)
+
+	// Users and API keys flagged for scraping
+	admin.Get("/usage-flags", usageHandler.GetUsageFlags)
+	admin.Post("/usage-flags/:id/resolve", usageHandler.ResolveUsageFlag)
+
+	// Compliance screening lists and the wallets found exposed
+	admin.Get("/compliance/exposure", complianceHandler.GetFlaggedExposures)
+	admin.Get("/compliance/lists", complianceHandler.GetScreeningLists)
+	admin.Post("/compliance/lists", complianceHandler.CreateScreeningList)
+	admin.Put("/compliance/lists/:id/addresses", complianceHandler.SetScreeningListAddresses)
+	admin.Delete("/compliance/lists/:id", complianceHandler.DeleteScreeningList)
+
+	// Encryption key rotation for stored secrets
+	admin.Post("/secrets/rotate", secretHandler.RotateSecrets)
+
+	// Bulk balance refreshes, processed by the worker
+	admin.Post("/balance-refreshes", balanceRefreshHandler.CreateBalanceRefresh)
+	admin.Get("/balance-refreshes", balanceRefreshHandler.GetBalanceRefreshes)
+	admin.Get("/balance-refreshes/:id", balanceRefreshHandler.GetBalanceRefresh)
+
 	// 404 handler
 	app.Use(func(c *fiber.Ctx) error {
 		return errors.NotFound("Route")
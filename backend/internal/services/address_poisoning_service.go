@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// AddressPoisoningService lists the lookalike addresses that poisoned a wallet's history
+// with zero-value transfers, flagged while the wallet backfill job imports it, so the
+// owner can be warned before sending to one by mistake.
+type AddressPoisoningService struct {
+	walletRepo    repos.WalletRepository
+	poisoningRepo repos.AddressPoisoningRepository
+}
+
+func NewAddressPoisoningService(walletRepo repos.WalletRepository, poisoningRepo repos.AddressPoisoningRepository) *AddressPoisoningService {
+	return &AddressPoisoningService{
+		walletRepo:    walletRepo,
+		poisoningRepo: poisoningRepo,
+	}
+}
+
+// GetCounterparties returns the poisoned counterparties flagged for one of the user's wallets
+func (s *AddressPoisoningService) GetCounterparties(ctx context.Context, userID, walletID uuid.UUID) ([]*models.PoisonedCounterparty, error) {
+	wallet, err := s.walletRepo.GetByID(ctx, walletID)
+	if err == repos.ErrWalletNotFound || (err == nil && wallet.UserID != userID) {
+		return nil, errors.NotFound("Wallet")
+	}
+	if err != nil {
+		logger.Error("Failed to get wallet", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to fetch wallet")
+	}
+
+	counterparties, err := s.poisoningRepo.GetByWallet(ctx, walletID)
+	if err != nil {
+		logger.Error("Failed to get poisoned counterparties", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to fetch poisoned counterparties")
+	}
+	return counterparties, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// AlchemyWebhookService receives Alchemy Notify deliveries. Each is checked against the
+// signing key of the webhook it claims to come from, then queued for the worker, which
+// ingests the activity for the wallets involved; the endpoint answers without waiting.
+type AlchemyWebhookService struct {
+	repo     repos.AlchemyWebhookRepository
+	webhooks map[string]blockchain.AlchemyWebhook
+}
+
+func NewAlchemyWebhookService(repo repos.AlchemyWebhookRepository, webhooks []blockchain.AlchemyWebhook) *AlchemyWebhookService {
+	byID := make(map[string]blockchain.AlchemyWebhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+	return &AlchemyWebhookService{repo: repo, webhooks: byID}
+}
+
+// Enabled reports whether any webhooks are configured
+func (s *AlchemyWebhookService) Enabled() bool {
+	return len(s.webhooks) > 0
+}
+
+// Receive verifies a delivery's signature and queues its event. Redelivered events are
+// accepted but queued only once.
+func (s *AlchemyWebhookService) Receive(ctx context.Context, body []byte, signature string) error {
+	webhookID, err := blockchain.AlchemyWebhookID(body)
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	webhook, ok := s.webhooks[webhookID]
+	if !ok || !blockchain.VerifyAlchemySignature(body, signature, webhook.SigningKey) {
+		return errors.Unauthorized("Invalid webhook signature")
+	}
+
+	parsed, err := blockchain.ParseAlchemyWebhook(body)
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	if parsed.ChainID != webhook.ChainID {
+		return errors.BadRequest("Webhook network doesn't match its chain")
+	}
+	if len(parsed.Addresses) == 0 {
+		return nil
+	}
+
+	event := &models.AlchemyWebhookEvent{
+		EventID:   parsed.ID,
+		WebhookID: parsed.WebhookID,
+		Type:      parsed.Type,
+		ChainID:   parsed.ChainID,
+		Addresses: parsed.Addresses,
+	}
+	if parsed.ToBlock > 0 {
+		event.FromBlock, event.ToBlock = &parsed.FromBlock, &parsed.ToBlock
+	}
+	queued, err := s.repo.InsertEvent(ctx, event)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !queued {
+		logger.Debug("Ignoring redelivered Alchemy webhook event", "eventId", parsed.ID)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAlchemyWebhookRepo struct {
+	repos.AlchemyWebhookRepository
+	events map[string]*models.AlchemyWebhookEvent
+}
+
+func (r *memoryAlchemyWebhookRepo) InsertEvent(ctx context.Context, event *models.AlchemyWebhookEvent) (bool, error) {
+	if _, ok := r.events[event.EventID]; ok {
+		return false, nil
+	}
+	r.events[event.EventID] = event
+	return true, nil
+}
+
+const testAlchemyDelivery = `{"webhookId": "wh_eth", "id": "whevt_1", "type": "ADDRESS_ACTIVITY",
+	"event": {"network": "ETH_MAINNET", "activity": [{
+		"fromAddress": "0x8ba1f109551bd432803012645ac136ddd64dba72",
+		"toAddress": "0x59479de9d374bdbcba6c791e5d036591976fe425",
+		"blockNum": "0x10", "hash": "0xabc"
+	}]}}`
+
+func signAlchemyDelivery(body, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestAlchemyWebhookServiceReceive(t *testing.T) {
+	repo := &memoryAlchemyWebhookRepo{events: map[string]*models.AlchemyWebhookEvent{}}
+	service := NewAlchemyWebhookService(repo, []blockchain.AlchemyWebhook{
+		{ID: "wh_eth", ChainID: 1, SigningKey: "whsec_eth"},
+		{ID: "wh_polygon", ChainID: 137, SigningKey: "whsec_polygon"},
+	})
+	ctx := context.Background()
+	body := []byte(testAlchemyDelivery)
+
+	// A delivery signed with its webhook's key is queued, and queued once when redelivered
+	require.NoError(t, service.Receive(ctx, body, signAlchemyDelivery(testAlchemyDelivery, "whsec_eth")))
+	require.NoError(t, service.Receive(ctx, body, signAlchemyDelivery(testAlchemyDelivery, "whsec_eth")))
+	require.Len(t, repo.events, 1)
+	event := repo.events["whevt_1"]
+	assert.Equal(t, 1, event.ChainID)
+	assert.Len(t, event.Addresses, 2)
+	assert.Equal(t, int64(16), *event.FromBlock)
+
+	// Another webhook's key doesn't verify it
+	err := service.Receive(ctx, body, signAlchemyDelivery(testAlchemyDelivery, "whsec_polygon"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.(*errors.AppError).Status)
+
+	// Nor does a delivery for a webhook that isn't configured
+	unknown := `{"webhookId": "wh_other", "id": "whevt_2"}`
+	err = service.Receive(ctx, []byte(unknown), signAlchemyDelivery(unknown, "whsec_eth"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.(*errors.AppError).Status)
+
+	// A signed delivery from a network other than the webhook's chain is rejected
+	mismatched := `{"webhookId": "wh_polygon", "id": "whevt_3", "type": "ADDRESS_ACTIVITY", "event": {"network": "ETH_MAINNET"}}`
+	err = service.Receive(ctx, []byte(mismatched), signAlchemyDelivery(mismatched, "whsec_polygon"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*errors.AppError).Status)
+	assert.Len(t, repo.events, 1)
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+const (
+	// defaultAlertBacktestDays is how far back alerts are replayed unless asked otherwise
+	defaultAlertBacktestDays = 30
+	// maxAlertBacktestDays is the longest range alerts are replayed over
+	maxAlertBacktestDays = 365
+	// liquidityChangeWindow is how far back liquidity alerts compare TVL with, as the
+	// alert evaluator does
+	liquidityChangeWindow = 24 * time.Hour
+)
+
+type alertBacktestHistory interface {
+	GetTokenPrices(ctx context.Context, address string, chainID int, from, to time.Time) ([]models.HistoryPoint, error)
+	GetPoolAPYs(ctx context.Context, poolID string, from, to time.Time) ([]models.HistoryPoint, error)
+	GetPoolTVLs(ctx context.Context, poolID string, from, to time.Time) ([]models.HistoryPoint, error)
+	GetProtocolTVLs(ctx context.Context, slug string, from, to time.Time) ([]models.HistoryPoint, error)
+}
+
+// AlertBacktester replays price, APR and liquidity alert definitions against the price,
+// APY and TVL snapshots the sync jobs record, so users can see how often a threshold
+// would have fired before creating the alert
+type AlertBacktester struct {
+	history alertBacktestHistory
+	clock   clock.Clock
+}
+
+func NewAlertBacktester(historyRepo repos.MetricHistoryRepository) *AlertBacktester {
+	return &AlertBacktester{
+		history: historyRepo,
+		clock:   clock.System,
+	}
+}
+
+// Backtest evaluates the alert at every snapshot of the series it watches in the
+// request's range
+func (b *AlertBacktester) Backtest(ctx context.Context, req *models.AlertBacktestRequest) (*models.AlertBacktestResult, error) {
+	switch req.Type {
+	case models.AlertTypePriceAbove, models.AlertTypePriceBelow, models.AlertTypeAPRChange, models.AlertTypeLiquidityChange:
+	default:
+		return nil, errors.BadRequest("Only price_above, price_below, apr_change and liquidity_change alerts can be backtested")
+	}
+	if err := ValidateBuiltinAlertConditions(req.Type, req.Conditions); err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+
+	to := b.clock.Now().UTC()
+	if req.To != nil {
+		to = req.To.UTC()
+	}
+	from := to.AddDate(0, 0, -defaultAlertBacktestDays)
+	if req.From != nil {
+		from = req.From.UTC()
+	}
+	if !from.Before(to) {
+		return nil, errors.BadRequest("from must be before to")
+	}
+	if to.Sub(from) > maxAlertBacktestDays*24*time.Hour {
+		return nil, errors.BadRequest(fmt.Sprintf("Backtests can cover at most %d days", maxAlertBacktestDays))
+	}
+
+	target := req.Target
+	var points []models.HistoryPoint
+	var match func(i int) map[string]interface{}
+	var err error
+
+	switch req.Type {
+	case models.AlertTypePriceAbove, models.AlertTypePriceBelow:
+		if err := target.ResolveAsset(); err != nil {
+			return nil, errors.BadRequest(err.Error())
+		}
+		if target.Type != "token" || target.Identifier == "" {
+			return nil, errors.BadRequest("Price alerts must target a token")
+		}
+		points, err = b.history.GetTokenPrices(ctx, target.Identifier, target.ChainID, from, to)
+		match = func(i int) map[string]interface{} {
+			return priceBacktestMatch(req.Type, req.Conditions, target, points[i].Value)
+		}
+	case models.AlertTypeAPRChange:
+		if target.Type != "pool" || target.Identifier == "" {
+			return nil, errors.BadRequest("APR alerts must target a pool")
+		}
+		points, err = b.history.GetPoolAPYs(ctx, target.Identifier, from, to)
+		match = func(i int) map[string]interface{} {
+			return aprBacktestMatch(req.Conditions, target.Identifier, points[i].Value)
+		}
+	case models.AlertTypeLiquidityChange:
+		// Snapshots from a window before the range are needed for the first changes
+		since := from.Add(-liquidityChangeWindow)
+		switch target.Type {
+		case "pool":
+			points, err = b.history.GetPoolTVLs(ctx, target.Identifier, since, to)
+		case "protocol":
+			points, err = b.history.GetProtocolTVLs(ctx, target.Identifier, since, to)
+		default:
+			return nil, errors.BadRequest("Liquidity alerts must target a pool or protocol")
+		}
+		changes := tvlChanges(points, liquidityChangeWindow)
+		match = func(i int) map[string]interface{} {
+			return liquidityBacktestMatch(req.Conditions, target, changes[i])
+		}
+	}
+	if err != nil {
+		logger.Error("Failed to get alert backtest history", "type", req.Type, "target", target.Identifier, "error", err)
+		return nil, errors.Internal("Failed to get history")
+	}
+
+	result := &models.AlertBacktestResult{
+		Type:     req.Type,
+		From:     from,
+		To:       to,
+		Triggers: []models.AlertBacktestTrigger{},
+	}
+	replayAlert(result, points, match)
+	return result, nil
+}
+
+// replayAlert evaluates match at each point in the result's range, recording a trigger
+// where it starts to hold. The live evaluator keeps firing while a condition holds,
+// subject to the alert's cooldown, so only the first point of each run is a trigger.
+func replayAlert(result *models.AlertBacktestResult, points []models.HistoryPoint, match func(i int) map[string]interface{}) {
+	held := false
+	for i, point := range points {
+		if point.Timestamp.Before(result.From) || !point.Timestamp.Before(result.To) {
+			continue
+		}
+		result.DataPoints++
+
+		triggeredValue := match(i)
+		if triggeredValue == nil {
+			held = false
+			continue
+		}
+		result.MatchedPoints++
+		if !held {
+			result.Triggers = append(result.Triggers, models.AlertBacktestTrigger{
+				TriggeredAt:    point.Timestamp,
+				TriggeredValue: triggeredValue,
+			})
+		}
+		held = true
+	}
+}
+
+func priceBacktestMatch(alertType string, conditions models.AlertConditions, target models.AlertTarget, price float64) map[string]interface{} {
+	if alertType == models.AlertTypePriceAbove && !(price > *conditions.Price) ||
+		alertType == models.AlertTypePriceBelow && !(price < *conditions.Price) {
+		return nil
+	}
+	return map[string]interface{}{
+		"currentPrice": price,
+		"tokenKey":     fmt.Sprintf("%s-%d", strings.ToLower(target.Identifier), target.ChainID),
+	}
+}
+
+func aprBacktestMatch(conditions models.AlertConditions, poolID string, apr float64) map[string]interface{} {
+	var reason string
+	if conditions.MinAPR != nil && apr < *conditions.MinAPR {
+		reason = "below_min_apr"
+	}
+	if conditions.MaxAPR != nil && apr > *conditions.MaxAPR {
+		reason = "above_max_apr"
+	}
+	if reason == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"currentAPR": apr,
+		"minAPR":     conditions.MinAPR,
+		"maxAPR":     conditions.MaxAPR,
+		"reason":     reason,
+		"poolId":     poolID,
+	}
+}
+
+func liquidityBacktestMatch(conditions models.AlertConditions, target models.AlertTarget, change *float64) map[string]interface{} {
+	threshold := *conditions.ChangePercent
+	if change == nil || (*change <= threshold && *change >= -threshold) {
+		return nil
+	}
+	triggeredValue := map[string]interface{}{
+		"tvlChangePercent": *change,
+		"threshold":        threshold,
+	}
+	if target.Type == "protocol" {
+		triggeredValue["protocolSlug"] = target.Identifier
+	} else {
+		triggeredValue["poolId"] = target.Identifier
+	}
+	return triggeredValue
+}
+
+// tvlChanges returns, for each point, the percent change from the latest point at least
+// window older, or nil when there is none or it was zero
+func tvlChanges(points []models.HistoryPoint, window time.Duration) []*float64 {
+	changes := make([]*float64, len(points))
+	previous := -1
+	for i, point := range points {
+		for previous+1 < i && !points[previous+1].Timestamp.After(point.Timestamp.Add(-window)) {
+			previous++
+		}
+		if previous < 0 || points[previous].Value == 0 {
+			continue
+		}
+		change := (point.Value - points[previous].Value) / points[previous].Value * 100
+		changes[i] = &change
+	}
+	return changes
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var backtestStart = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// backtestHistory serves the same series for every target, filtered to the range asked
+// for
+type backtestHistory []models.HistoryPoint
+
+func (h backtestHistory) series(from, to time.Time) ([]models.HistoryPoint, error) {
+	points := []models.HistoryPoint{}
+	for _, point := range h {
+		if !point.Timestamp.Before(from) && point.Timestamp.Before(to) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+func (h backtestHistory) GetTokenPrices(_ context.Context, _ string, _ int, from, to time.Time) ([]models.HistoryPoint, error) {
+	return h.series(from, to)
+}
+
+func (h backtestHistory) GetPoolAPYs(_ context.Context, _ string, from, to time.Time) ([]models.HistoryPoint, error) {
+	return h.series(from, to)
+}
+
+func (h backtestHistory) GetPoolTVLs(_ context.Context, _ string, from, to time.Time) ([]models.HistoryPoint, error) {
+	return h.series(from, to)
+}
+
+func (h backtestHistory) GetProtocolTVLs(_ context.Context, _ string, from, to time.Time) ([]models.HistoryPoint, error) {
+	return h.series(from, to)
+}
+
+// hourly builds a series with one point an hour from backtestStart
+func hourly(values ...float64) backtestHistory {
+	points := make(backtestHistory, len(values))
+	for i, value := range values {
+		points[i] = models.HistoryPoint{Timestamp: backtestStart.Add(time.Duration(i) * time.Hour), Value: value}
+	}
+	return points
+}
+
+func newBacktester(history backtestHistory) *AlertBacktester {
+	return &AlertBacktester{
+		history: history,
+		clock:   clock.NewFake(backtestStart.AddDate(0, 0, 10)),
+	}
+}
+
+func TestAlertBacktestPriceTriggersWhenConditionStartsToHold(t *testing.T) {
+	b := newBacktester(hourly(1900, 2100, 2200, 1950, 2050, 2000))
+	price := 2000.0
+
+	result, err := b.Backtest(context.Background(), &models.AlertBacktestRequest{
+		Type:       models.AlertTypePriceAbove,
+		Target:     models.AlertTarget{Type: "token", Identifier: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", ChainID: 1},
+		Conditions: models.AlertConditions{Price: &price},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, result.DataPoints)
+	assert.Equal(t, 3, result.MatchedPoints)
+	require.Len(t, result.Triggers, 2)
+	assert.Equal(t, backtestStart.Add(time.Hour), result.Triggers[0].TriggeredAt)
+	assert.Equal(t, 2100.0, result.Triggers[0].TriggeredValue["currentPrice"])
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48-1", result.Triggers[0].TriggeredValue["tokenKey"])
+	assert.Equal(t, backtestStart.Add(4*time.Hour), result.Triggers[1].TriggeredAt)
+	// Defaults to the last 30 days
+	assert.Equal(t, backtestStart.AddDate(0, 0, -20), result.From)
+}
+
+func TestAlertBacktestAPRUsesRange(t *testing.T) {
+	b := newBacktester(hourly(3, 8, 9, 2, 12))
+	minAPR, maxAPR := 4.0, 10.0
+	from, to := backtestStart.Add(time.Hour), backtestStart.Add(4*time.Hour)
+
+	result, err := b.Backtest(context.Background(), &models.AlertBacktestRequest{
+		Type:       models.AlertTypeAPRChange,
+		Target:     models.AlertTarget{Type: "pool", Identifier: "pool-1"},
+		Conditions: models.AlertConditions{MinAPR: &minAPR, MaxAPR: &maxAPR},
+		From:       &from,
+		To:         &to,
+	})
+	require.NoError(t, err)
+
+	// The first and last points are outside the range
+	assert.Equal(t, 3, result.DataPoints)
+	require.Len(t, result.Triggers, 1)
+	assert.Equal(t, backtestStart.Add(3*time.Hour), result.Triggers[0].TriggeredAt)
+	assert.Equal(t, "below_min_apr", result.Triggers[0].TriggeredValue["reason"])
+	assert.Equal(t, "pool-1", result.Triggers[0].TriggeredValue["poolId"])
+}
+
+func TestAlertBacktestLiquidityComparesWithDayEarlier(t *testing.T) {
+	history := backtestHistory{
+		{Timestamp: backtestStart.Add(-2 * time.Hour), Value: 100},
+		{Timestamp: backtestStart.Add(12 * time.Hour), Value: 50},
+		// 26 hours after the first point: -20%
+		{Timestamp: backtestStart.Add(24 * time.Hour), Value: 80},
+		// Against the point 24 hours earlier: +100%
+		{Timestamp: backtestStart.Add(36 * time.Hour), Value: 100},
+	}
+	b := newBacktester(history)
+	threshold := 25.0
+	from := backtestStart
+
+	result, err := b.Backtest(context.Background(), &models.AlertBacktestRequest{
+		Type:       models.AlertTypeLiquidityChange,
+		Target:     models.AlertTarget{Type: "protocol", Identifier: "aave-v3"},
+		Conditions: models.AlertConditions{ChangePercent: &threshold},
+		From:       &from,
+	})
+	require.NoError(t, err)
+
+	// The point before the range is only compared against
+	assert.Equal(t, 3, result.DataPoints)
+	require.Len(t, result.Triggers, 1)
+	assert.Equal(t, backtestStart.Add(36*time.Hour), result.Triggers[0].TriggeredAt)
+	assert.InDelta(t, 100.0, result.Triggers[0].TriggeredValue["tvlChangePercent"], 0.001)
+	assert.Equal(t, "aave-v3", result.Triggers[0].TriggeredValue["protocolSlug"])
+}
+
+func TestAlertBacktestRejectsInvalidRequests(t *testing.T) {
+	b := newBacktester(nil)
+	price := 10.0
+	from := backtestStart.AddDate(-2, 0, 0)
+
+	tests := []struct {
+		name string
+		req  models.AlertBacktestRequest
+	}{
+		{"unsupported type", models.AlertBacktestRequest{Type: models.AlertTypeLargeTransfer}},
+		{"missing condition", models.AlertBacktestRequest{Type: models.AlertTypePriceAbove, Target: models.AlertTarget{Type: "token", Identifier: "0x1", ChainID: 1}}},
+		{"wrong target", models.AlertBacktestRequest{Type: models.AlertTypePriceBelow, Target: models.AlertTarget{Type: "pool", Identifier: "pool-1"}, Conditions: models.AlertConditions{Price: &price}}},
+		{"range too long", models.AlertBacktestRequest{Type: models.AlertTypePriceBelow, Target: models.AlertTarget{Type: "token", Identifier: "0x1", ChainID: 1}, Conditions: models.AlertConditions{Price: &price}, From: &from}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.Backtest(context.Background(), &tt.req)
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, 400, appErr.Status)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+const (
+	// maxConditionDepth and maxConditionNodes keep composite rules cheap to evaluate
+	maxConditionDepth = 5
+	maxConditionNodes = 25
+)
+
+// validateConditionTree checks a composite alert rule for structure and known metrics
+func validateConditionTree(rule *models.AlertConditionNode) error {
+	if rule == nil {
+		return fmt.Errorf("rule must be specified for composite alerts")
+	}
+
+	nodes := 0
+	return validateConditionNode(rule, 1, false, &nodes)
+}
+
+func validateConditionNode(node *models.AlertConditionNode, depth int, inPoolScope bool, nodes *int) error {
+	*nodes++
+	if *nodes > maxConditionNodes {
+		return fmt.Errorf("rule has more than %d conditions", maxConditionNodes)
+	}
+	if depth > maxConditionDepth {
+		return fmt.Errorf("rule is nested deeper than %d levels", maxConditionDepth)
+	}
+
+	if node.Operator != "" {
+		if node.Metric != "" {
+			return fmt.Errorf("a condition cannot set both operator and metric")
+		}
+
+		switch node.Operator {
+		case models.ConditionOperatorAnd, models.ConditionOperatorOr:
+		case models.ConditionOperatorAnyPool:
+			if inPoolScope {
+				return fmt.Errorf("any_pool cannot be nested inside another any_pool")
+			}
+			inPoolScope = true
+		default:
+			return fmt.Errorf("unknown operator: %s", node.Operator)
+		}
+
+		if len(node.Children) == 0 {
+			return fmt.Errorf("%s requires at least one child condition", node.Operator)
+		}
+		for i := range node.Children {
+			if err := validateConditionNode(&node.Children[i], depth+1, inPoolScope, nodes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var targetType string
+	switch node.Metric {
+	case models.ConditionMetricTokenPrice:
+		targetType = "token"
+	case models.ConditionMetricPoolAPY, models.ConditionMetricPoolTVLUSD:
+		targetType = "pool"
+	case models.ConditionMetricGasPriceGwei:
+		// Uses the target chain only
+	case "":
+		return fmt.Errorf("condition must set either operator or metric")
+	default:
+		return fmt.Errorf("unknown metric: %s", node.Metric)
+	}
+
+	if node.Target != nil && targetType != "" && node.Target.Type != targetType {
+		return fmt.Errorf("metric %s requires a %s target", node.Metric, targetType)
+	}
+
+	switch node.Comparator {
+	case models.ConditionComparatorGT, models.ConditionComparatorGTE, models.ConditionComparatorLT, models.ConditionComparatorLTE:
+	default:
+		return fmt.Errorf("unknown comparator %q for metric %s", node.Comparator, node.Metric)
+	}
+
+	if node.Value == nil {
+		return fmt.Errorf("value must be specified for metric %s", node.Metric)
+	}
+
+	return nil
+}
+
+// validateConditionTargets checks that every pool_* metric outside an any_pool scope
+// has a pool to read, from its own target or the alert's
+func validateConditionTargets(node *models.AlertConditionNode, target models.AlertTarget) error {
+	if node == nil {
+		return nil
+	}
+	if node.Operator == models.ConditionOperatorAnyPool {
+		return nil
+	}
+	for i := range node.Children {
+		if err := validateConditionTargets(&node.Children[i], target); err != nil {
+			return err
+		}
+	}
+
+	switch node.Metric {
+	case models.ConditionMetricPoolAPY, models.ConditionMetricPoolTVLUSD:
+		if node.Target == nil && target.Type != "pool" {
+			return fmt.Errorf("metric %s needs a pool target or an any_pool condition", node.Metric)
+		}
+	}
+	return nil
+}
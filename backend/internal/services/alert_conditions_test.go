@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConditionTree(t *testing.T) {
+	value := 10.0
+	leaf := func(metric, comparator string) models.AlertConditionNode {
+		return models.AlertConditionNode{Metric: metric, Comparator: comparator, Value: &value}
+	}
+	group := func(operator string, children ...models.AlertConditionNode) models.AlertConditionNode {
+		return models.AlertConditionNode{Operator: operator, Children: children}
+	}
+	// nested wraps a leaf in n-1 and groups, n levels deep in total
+	nested := func(n int) models.AlertConditionNode {
+		node := leaf(models.ConditionMetricGasPriceGwei, models.ConditionComparatorLT)
+		for i := 1; i < n; i++ {
+			node = group(models.ConditionOperatorAnd, node)
+		}
+		return node
+	}
+	price := leaf(models.ConditionMetricTokenPrice, models.ConditionComparatorGT)
+	gas := leaf(models.ConditionMetricGasPriceGwei, models.ConditionComparatorLTE)
+
+	tests := []struct {
+		name    string
+		rule    models.AlertConditionNode
+		wantErr string
+	}{
+		{"single leaf", price, ""},
+		{"and of leaves", group(models.ConditionOperatorAnd, price, gas), ""},
+		{"or inside and", group(models.ConditionOperatorAnd, price, group(models.ConditionOperatorOr, gas, price)), ""},
+		{"and inside or", group(models.ConditionOperatorOr, group(models.ConditionOperatorAnd, price, gas), gas), ""},
+		{"any_pool", group(models.ConditionOperatorAnyPool, leaf(models.ConditionMetricPoolAPY, models.ConditionComparatorGTE)), ""},
+		{"nested any_pool", group(models.ConditionOperatorAnyPool, group(models.ConditionOperatorAnyPool, price)), "cannot be nested"},
+		{"empty group", group(models.ConditionOperatorOr), "at least one child"},
+		{"unknown operator", group("xor", price), "unknown operator"},
+		{"operator and metric", models.AlertConditionNode{Operator: models.ConditionOperatorAnd, Metric: models.ConditionMetricTokenPrice, Children: []models.AlertConditionNode{price}}, "both operator and metric"},
+		{"neither operator nor metric", models.AlertConditionNode{}, "either operator or metric"},
+		{"unknown metric", leaf("volume", models.ConditionComparatorGT), "unknown metric"},
+		{"missing value", models.AlertConditionNode{Metric: models.ConditionMetricTokenPrice, Comparator: models.ConditionComparatorGT}, "value must be specified"},
+		{"wrong target type", models.AlertConditionNode{Metric: models.ConditionMetricPoolAPY, Comparator: models.ConditionComparatorGT, Value: &value, Target: &models.AlertTarget{Type: "token"}}, "requires a pool target"},
+		{"at the depth limit", nested(maxConditionDepth), ""},
+		{"past the depth limit", nested(maxConditionDepth + 1), "nested deeper than"},
+	}
+	for _, comparator := range []string{models.ConditionComparatorGT, models.ConditionComparatorGTE, models.ConditionComparatorLT, models.ConditionComparatorLTE} {
+		tests = append(tests, struct {
+			name    string
+			rule    models.AlertConditionNode
+			wantErr string
+		}{"comparator " + comparator, leaf(models.ConditionMetricTokenPrice, comparator), ""})
+	}
+	tests = append(tests, struct {
+		name    string
+		rule    models.AlertConditionNode
+		wantErr string
+	}{"unknown comparator", leaf(models.ConditionMetricTokenPrice, "eq"), "unknown comparator"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConditionTree(&tt.rule)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	assert.Error(t, validateConditionTree(nil))
+
+	children := make([]models.AlertConditionNode, maxConditionNodes)
+	for i := range children {
+		children[i] = price
+	}
+	wide := group(models.ConditionOperatorOr, children...)
+	assert.ErrorContains(t, validateConditionTree(&wide), "more than")
+}
+
+func TestValidateConditionTargets(t *testing.T) {
+	value := 5.0
+	apy := models.AlertConditionNode{Metric: models.ConditionMetricPoolAPY, Comparator: models.ConditionComparatorGT, Value: &value}
+	pinned := apy
+	pinned.Target = &models.AlertTarget{Type: "pool", Identifier: "pool-1"}
+	gas := models.AlertConditionNode{Metric: models.ConditionMetricGasPriceGwei, Comparator: models.ConditionComparatorLT, Value: &value}
+	and := func(children ...models.AlertConditionNode) *models.AlertConditionNode {
+		return &models.AlertConditionNode{Operator: models.ConditionOperatorAnd, Children: children}
+	}
+	token := models.AlertTarget{Type: "token", Identifier: "0xabc", ChainID: 1}
+	pool := models.AlertTarget{Type: "pool", Identifier: "pool-1"}
+
+	// pool_* metrics read the alert's pool, their own, or the one any_pool binds
+	assert.NoError(t, validateConditionTargets(and(apy, gas), pool))
+	assert.NoError(t, validateConditionTargets(and(pinned, gas), token))
+	assert.NoError(t, validateConditionTargets(&models.AlertConditionNode{Operator: models.ConditionOperatorAnyPool, Children: []models.AlertConditionNode{apy}}, token))
+	assert.NoError(t, validateConditionTargets(and(gas), token))
+
+	assert.ErrorContains(t, validateConditionTargets(and(gas, apy), token), "needs a pool target")
+	assert.Error(t, validateConditionTargets(&apy, models.AlertTarget{}))
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// maxAlertConfigSize caps how many alerts a config declares
+const maxAlertConfigSize = 200
+
+// alertConfigKeyPattern is what keys naming managed alerts look like
+var alertConfigKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_./-]{0,99}$`)
+
+// AlertConfigService converges a user's alerts on a declarative config, so power users
+// can keep their alerts in version control. Alerts in a config are named by keys; the
+// service creates, updates, replaces and deletes the user's keyed alerts to match, and
+// leaves alerts created any other way alone.
+type AlertConfigService struct {
+	alerts AlertService
+	keys   repos.AlertConfigRepository
+	// uow makes each change, like a replacement's create and delete, whole or not at all
+	uow repos.UnitOfWork
+}
+
+func NewAlertConfigService(alertService AlertService, keys repos.AlertConfigRepository) *AlertConfigService {
+	return &AlertConfigService{alerts: alertService, keys: keys, uow: repos.NoUnitOfWork}
+}
+
+// SetUnitOfWork makes each change an apply makes commit all its writes or none
+func (s *AlertConfigService) SetUnitOfWork(uow repos.UnitOfWork) {
+	s.uow = uow
+}
+
+// alertConfigStep is a planned change with what's needed to make it
+type alertConfigStep struct {
+	change   models.AlertConfigChange
+	entry    *models.AlertConfigEntry
+	existing *models.Alert
+	update   *models.UpdateAlertRequest
+}
+
+// Export returns the user's managed alerts as a config, ordered by key
+func (s *AlertConfigService) Export(ctx context.Context, userID uuid.UUID) (*models.AlertConfig, error) {
+	managed, err := s.managedAlerts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(managed))
+	for key := range managed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	config := &models.AlertConfig{Alerts: make([]models.AlertConfigEntry, 0, len(keys))}
+	for _, key := range keys {
+		alert := managed[key]
+		status := models.AlertStatusActive
+		if alert.Status == models.AlertStatusDisabled {
+			status = models.AlertStatusDisabled
+		}
+		config.Alerts = append(config.Alerts, models.AlertConfigEntry{
+			Key:          key,
+			Type:         alert.Type,
+			Status:       status,
+			Target:       alert.Target,
+			Conditions:   alert.Conditions,
+			Notification: alert.Notification,
+		})
+	}
+	return config, nil
+}
+
+// Apply plans the changes that make the user's managed alerts match the config and,
+// unless dryRun, makes them. A change the alert service rejects is reported on the
+// plan and doesn't stop the others.
+func (s *AlertConfigService) Apply(ctx context.Context, userID uuid.UUID, config *models.AlertConfig, dryRun bool) (*models.AlertConfigPlan, error) {
+	if err := validateAlertConfig(config); err != nil {
+		return nil, err
+	}
+	managed, err := s.managedAlerts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, unchanged := planAlertConfig(config, managed)
+	plan := &models.AlertConfigPlan{
+		Applied:   !dryRun,
+		Changes:   make([]models.AlertConfigChange, 0, len(steps)),
+		Unchanged: unchanged,
+	}
+	for _, step := range steps {
+		if !dryRun {
+			err := s.uow.Do(ctx, func(ctx context.Context) error {
+				return s.applyStep(ctx, userID, step)
+			})
+			if err != nil {
+				step.change.Error = err.Error()
+				if appErr, ok := err.(*errors.AppError); ok {
+					step.change.Error = appErr.Message
+				}
+			}
+		}
+		plan.Changes = append(plan.Changes, step.change)
+	}
+
+	if !dryRun && len(steps) > 0 {
+		logger.Info("Applied alert config", "userID", userID, "changes", len(steps), "unchanged", unchanged)
+	}
+	return plan, nil
+}
+
+// managedAlerts returns the user's keyed alerts by key
+func (s *AlertConfigService) managedAlerts(ctx context.Context, userID uuid.UUID) (map[string]*models.Alert, error) {
+	keys, err := s.keys.GetKeys(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	managed := make(map[string]*models.Alert, len(keys))
+	for key, alertID := range keys {
+		alert, err := s.alerts.GetAlert(ctx, alertID, userID)
+		if err != nil {
+			logger.Error("Failed to get managed alert", "error", err, "alertID", alertID)
+			return nil, errors.Internal("Failed to fetch alerts")
+		}
+		managed[key] = alert
+	}
+	return managed, nil
+}
+
+func validateAlertConfig(config *models.AlertConfig) error {
+	if len(config.Alerts) > maxAlertConfigSize {
+		return errors.BadRequest(fmt.Sprintf("A config declares at most %d alerts", maxAlertConfigSize))
+	}
+
+	seen := make(map[string]bool, len(config.Alerts))
+	for i, entry := range config.Alerts {
+		if !alertConfigKeyPattern.MatchString(entry.Key) {
+			return errors.BadRequest(fmt.Sprintf("Alert %d: key must be 1 to 100 letters, digits, '_', '.', '/' or '-'", i+1))
+		}
+		if seen[entry.Key] {
+			return errors.BadRequest(fmt.Sprintf("Alert %d: duplicate key %q", i+1, entry.Key))
+		}
+		seen[entry.Key] = true
+		if entry.Type == "" {
+			return errors.BadRequest(fmt.Sprintf("Alert %q: type is required", entry.Key))
+		}
+		if entry.Status != "" && entry.Status != models.AlertStatusActive && entry.Status != models.AlertStatusDisabled {
+			return errors.BadRequest(fmt.Sprintf("Alert %q: status must be active or disabled", entry.Key))
+		}
+	}
+	return nil
+}
+
+// planAlertConfig diffs the config against the managed alerts: creates and updates in
+// config order, then deletes by key. It also returns how many alerts already match.
+func planAlertConfig(config *models.AlertConfig, managed map[string]*models.Alert) ([]*alertConfigStep, int) {
+	var steps []*alertConfigStep
+	unchanged := 0
+	for i := range config.Alerts {
+		entry := &config.Alerts[i]
+		existing, ok := managed[entry.Key]
+		if !ok {
+			steps = append(steps, &alertConfigStep{
+				change: models.AlertConfigChange{Action: models.AlertConfigCreate, Key: entry.Key},
+				entry:  entry,
+			})
+			continue
+		}
+
+		id := existing.ID
+		// Alerts can't change type or target in place
+		var replaced []string
+		if entry.Type != existing.Type {
+			replaced = append(replaced, "type")
+		}
+		if !sameAlertConfigTarget(entry.Target, existing.Target) {
+			replaced = append(replaced, "target")
+		}
+		if len(replaced) > 0 {
+			steps = append(steps, &alertConfigStep{
+				change:   models.AlertConfigChange{Action: models.AlertConfigReplace, Key: entry.Key, AlertID: &id, Fields: replaced},
+				entry:    entry,
+				existing: existing,
+			})
+			continue
+		}
+
+		update := &models.UpdateAlertRequest{}
+		var fields []string
+		if !sameJSON(entry.Conditions, existing.Conditions) {
+			update.Conditions = &entry.Conditions
+			fields = append(fields, "conditions")
+		}
+		if !sameJSON(entry.Notification, existing.Notification) {
+			update.Notification = &entry.Notification
+			fields = append(fields, "notification")
+		}
+		// Triggered and expired alerts are still active as far as the config goes
+		if disabled := entry.Status == models.AlertStatusDisabled; disabled != (existing.Status == models.AlertStatusDisabled) {
+			status := models.AlertStatusActive
+			if disabled {
+				status = models.AlertStatusDisabled
+			}
+			update.Status = &status
+			fields = append(fields, "status")
+		}
+		if len(fields) == 0 {
+			unchanged++
+			continue
+		}
+		steps = append(steps, &alertConfigStep{
+			change:   models.AlertConfigChange{Action: models.AlertConfigUpdate, Key: entry.Key, AlertID: &id, Fields: fields},
+			entry:    entry,
+			existing: existing,
+			update:   update,
+		})
+	}
+
+	declared := make(map[string]bool, len(config.Alerts))
+	for _, entry := range config.Alerts {
+		declared[entry.Key] = true
+	}
+	var removed []string
+	for key := range managed {
+		if !declared[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	for _, key := range removed {
+		id := managed[key].ID
+		steps = append(steps, &alertConfigStep{
+			change:   models.AlertConfigChange{Action: models.AlertConfigDelete, Key: key, AlertID: &id},
+			existing: managed[key],
+		})
+	}
+	return steps, unchanged
+}
+
+func (s *AlertConfigService) applyStep(ctx context.Context, userID uuid.UUID, step *alertConfigStep) error {
+	switch step.change.Action {
+	case models.AlertConfigCreate, models.AlertConfigReplace:
+		// A replacement is created first so a rejected one leaves the old alert in place
+		alert, err := s.createManaged(ctx, userID, step.entry)
+		if err != nil {
+			return err
+		}
+		step.change.AlertID = &alert.ID
+		if step.existing != nil {
+			return s.alerts.DeleteAlert(ctx, step.existing.ID, userID)
+		}
+		return nil
+	case models.AlertConfigUpdate:
+		_, err := s.alerts.UpdateAlert(ctx, step.existing.ID, userID, step.update)
+		return err
+	case models.AlertConfigDelete:
+		return s.alerts.DeleteAlert(ctx, step.existing.ID, userID)
+	default:
+		return fmt.Errorf("unknown alert config action %q", step.change.Action)
+	}
+}
+
+func (s *AlertConfigService) createManaged(ctx context.Context, userID uuid.UUID, entry *models.AlertConfigEntry) (*models.Alert, error) {
+	alert, err := s.alerts.CreateAlert(ctx, userID, &models.CreateAlertRequest{
+		Type:         entry.Type,
+		Target:       entry.Target,
+		Conditions:   entry.Conditions,
+		Notification: entry.Notification,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// An unkeyed alert would be created again on the next apply. A unit of work rolls it
+	// back, but without one every write has already committed, so it's deleted.
+	if err := s.keys.SetKey(ctx, userID, alert.ID, entry.Key); err != nil {
+		logger.Error("Failed to set alert config key", "error", err, "alertID", alert.ID)
+		if err := s.alerts.DeleteAlert(ctx, alert.ID, userID); err != nil {
+			logger.Error("Failed to delete unkeyed alert", "error", err, "alertID", alert.ID)
+		}
+		return nil, errors.DatabaseError(err)
+	}
+	if entry.Status == models.AlertStatusDisabled {
+		status := models.AlertStatusDisabled
+		if _, err := s.alerts.UpdateAlert(ctx, alert.ID, userID, &models.UpdateAlertRequest{Status: &status}); err != nil {
+			return nil, err
+		}
+	}
+	return alert, nil
+}
+
+// sameAlertConfigTarget reports whether a declared target is the alert's. Targets are
+// resolved as they are on creation, and portfolio-wide alerts may leave theirs out.
+func sameAlertConfigTarget(declared, actual models.AlertTarget) bool {
+	if actual.Type == models.AlertTargetTypePortfolio && (declared.Type == "" || declared.Type == models.AlertTargetTypePortfolio) {
+		return true
+	}
+	if err := declared.ResolveAsset(); err != nil {
+		return false
+	}
+	return declared.Type == actual.Type &&
+		declared.ChainID == actual.ChainID &&
+		strings.EqualFold(declared.Identifier, actual.Identifier)
+}
+
+func sameJSON(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
\ No newline at end of file
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configAlerts keeps the user's alerts in memory, rejecting price alerts without a price
+type configAlerts struct {
+	AlertService
+	alerts map[uuid.UUID]*models.Alert
+}
+
+func (a *configAlerts) GetAlert(_ context.Context, alertID, _ uuid.UUID) (*models.Alert, error) {
+	alert, ok := a.alerts[alertID]
+	if !ok {
+		return nil, repos.ErrAlertNotFound
+	}
+	copied := *alert
+	return &copied, nil
+}
+
+func (a *configAlerts) CreateAlert(_ context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.Alert, error) {
+	if req.Type == models.AlertTypePriceAbove && req.Conditions.Price == nil {
+		return nil, fmt.Errorf("invalid alert conditions: price alerts require price")
+	}
+	alert := &models.Alert{ID: uuid.New(), UserID: userID, Type: req.Type, Status: models.AlertStatusActive,
+		Target: req.Target, Conditions: req.Conditions, Notification: req.Notification}
+	a.alerts[alert.ID] = alert
+	return alert, nil
+}
+
+func (a *configAlerts) UpdateAlert(_ context.Context, alertID, _ uuid.UUID, req *models.UpdateAlertRequest) (*models.Alert, error) {
+	alert := a.alerts[alertID]
+	if req.Status != nil {
+		alert.Status = *req.Status
+	}
+	if req.Conditions != nil {
+		alert.Conditions = *req.Conditions
+	}
+	if req.Notification != nil {
+		alert.Notification = *req.Notification
+	}
+	return alert, nil
+}
+
+func (a *configAlerts) DeleteAlert(_ context.Context, alertID, _ uuid.UUID) error {
+	delete(a.alerts, alertID)
+	return nil
+}
+
+// configKeys mirrors alert_config_keys, dropping keys of deleted alerts like the cascade
+type configKeys struct {
+	repos.AlertConfigRepository
+	alerts *configAlerts
+	keys   map[string]uuid.UUID
+	err    error
+}
+
+func (k *configKeys) GetKeys(context.Context, uuid.UUID) (map[string]uuid.UUID, error) {
+	keys := make(map[string]uuid.UUID)
+	for key, id := range k.keys {
+		if _, ok := k.alerts.alerts[id]; ok {
+			keys[key] = id
+		}
+	}
+	return keys, nil
+}
+
+func (k *configKeys) SetKey(_ context.Context, _, alertID uuid.UUID, key string) error {
+	if k.err != nil {
+		return k.err
+	}
+	k.keys[key] = alertID
+	return nil
+}
+
+func TestAlertConfigApply(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	price, higher := 2000.0, 2500.0
+	eth := models.AlertTarget{Type: "token", Identifier: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", ChainID: 1}
+
+	alerts := &configAlerts{alerts: map[uuid.UUID]*models.Alert{}}
+	keys := &configKeys{alerts: alerts, keys: map[string]uuid.UUID{}}
+	service := NewAlertConfigService(alerts, keys)
+	// An alert made in the UI isn't managed
+	unmanaged, _ := alerts.CreateAlert(ctx, userID, &models.CreateAlertRequest{Type: models.AlertTypePriceBelow, Target: eth, Conditions: models.AlertConditions{Price: &price}})
+
+	config := &models.AlertConfig{Alerts: []models.AlertConfigEntry{
+		{Key: "eth/above", Type: models.AlertTypePriceAbove, Target: eth, Conditions: models.AlertConditions{Price: &price}, Notification: models.AlertNotification{Email: true}},
+		{Key: "portfolio-drop", Type: models.AlertTypePortfolioValue, Status: models.AlertStatusDisabled, Conditions: models.AlertConditions{ValueBelow: &price}},
+	}}
+
+	// A dry run only plans
+	plan, err := service.Apply(ctx, userID, config, true)
+	require.NoError(t, err)
+	assert.False(t, plan.Applied)
+	require.Len(t, plan.Changes, 2)
+	assert.Equal(t, models.AlertConfigCreate, plan.Changes[0].Action)
+	assert.Len(t, alerts.alerts, 1)
+
+	plan, err = service.Apply(ctx, userID, config, false)
+	require.NoError(t, err)
+	assert.True(t, plan.Applied)
+	require.Len(t, plan.Changes, 2)
+	assert.Empty(t, plan.Changes[0].Error)
+	assert.Len(t, alerts.alerts, 3)
+	assert.Equal(t, models.AlertStatusDisabled, alerts.alerts[keys.keys["portfolio-drop"]].Status)
+	alerts.alerts[keys.keys["portfolio-drop"]].Target = models.AlertTarget{Type: models.AlertTargetTypePortfolio}
+
+	// Applying it again changes nothing, whatever the address's case
+	config.Alerts[0].Target.Identifier = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	plan, err = service.Apply(ctx, userID, config, false)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+	assert.Equal(t, 2, plan.Unchanged)
+
+	// Edited conditions update in place, a new type replaces, and dropped keys are deleted
+	ethID, dropID := keys.keys["eth/above"], keys.keys["portfolio-drop"]
+	config.Alerts[0].Conditions.Price = &higher
+	config.Alerts[0].Status = models.AlertStatusDisabled
+	config.Alerts[1].Type = models.AlertTypeBudgetExceeded
+	config.Alerts = append(config.Alerts, models.AlertConfigEntry{Key: "broken", Type: models.AlertTypePriceAbove, Target: eth})
+	plan, err = service.Apply(ctx, userID, config, false)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, models.AlertConfigChange{Action: models.AlertConfigUpdate, Key: "eth/above", AlertID: &ethID, Fields: []string{"conditions", "status"}}, plan.Changes[0])
+	assert.Equal(t, models.AlertConfigReplace, plan.Changes[1].Action)
+	assert.Equal(t, []string{"type"}, plan.Changes[1].Fields)
+	assert.Equal(t, keys.keys["portfolio-drop"], *plan.Changes[1].AlertID)
+	assert.NotContains(t, alerts.alerts, dropID)
+	assert.Equal(t, models.AlertTypeBudgetExceeded, alerts.alerts[keys.keys["portfolio-drop"]].Type)
+	assert.Equal(t, "invalid alert conditions: price alerts require price", plan.Changes[2].Error)
+	assert.Equal(t, 2500.0, *alerts.alerts[ethID].Conditions.Price)
+
+	config.Alerts = config.Alerts[:1]
+	plan, err = service.Apply(ctx, userID, config, false)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, models.AlertConfigDelete, plan.Changes[0].Action)
+	assert.Equal(t, "portfolio-drop", plan.Changes[0].Key)
+	assert.Len(t, alerts.alerts, 2)
+	assert.Contains(t, alerts.alerts, unmanaged.ID)
+
+	exported, err := service.Export(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, exported.Alerts, 1)
+	assert.Equal(t, "eth/above", exported.Alerts[0].Key)
+	assert.Equal(t, models.AlertStatusDisabled, exported.Alerts[0].Status)
+
+	for _, bad := range []models.AlertConfigEntry{
+		{Key: "", Type: models.AlertTypePriceAbove},
+		{Key: "has space", Type: models.AlertTypePriceAbove},
+		{Key: "no-type"},
+		{Key: "paused", Type: models.AlertTypePriceAbove, Status: "paused"},
+	} {
+		_, err := service.Apply(ctx, userID, &models.AlertConfig{Alerts: []models.AlertConfigEntry{bad}}, true)
+		require.Error(t, err, bad.Key)
+		assert.Equal(t, 400, err.(*errors.AppError).Status)
+	}
+	_, err = service.Apply(ctx, userID, &models.AlertConfig{Alerts: []models.AlertConfigEntry{config.Alerts[0], config.Alerts[0]}}, true)
+	assert.Contains(t, err.Error(), "duplicate key")
+}
+
+func TestAlertConfigApplyFailedKey(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	price := 2000.0
+	alerts := &configAlerts{alerts: map[uuid.UUID]*models.Alert{}}
+	keys := &configKeys{alerts: alerts, keys: map[string]uuid.UUID{}, err: fmt.Errorf("connection reset")}
+	service := NewAlertConfigService(alerts, keys)
+
+	// Without a unit of work to roll it back, an alert whose key wasn't saved is deleted
+	config := &models.AlertConfig{Alerts: []models.AlertConfigEntry{
+		{Key: "portfolio-drop", Type: models.AlertTypePortfolioValue, Conditions: models.AlertConditions{ValueBelow: &price}},
+	}}
+	plan, err := service.Apply(ctx, userID, config, false)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.NotEmpty(t, plan.Changes[0].Error)
+	assert.Empty(t, alerts.alerts)
+
+	keys.err = nil
+	plan, err = service.Apply(ctx, userID, config, false)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, models.AlertConfigCreate, plan.Changes[0].Action)
+	assert.Len(t, alerts.alerts, 1)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+const (
+	// AlertEvaluationInterval is how often the worker evaluates active alerts
+	AlertEvaluationInterval = 5 * time.Minute
+	// AlertCoverageRetention is how long alerts' hourly coverage is kept
+	AlertCoverageRetention = 30 * 24 * time.Hour
+	// DefaultAlertCoverageWindow is how far back coverage is reported unless asked otherwise
+	DefaultAlertCoverageWindow = 24 * time.Hour
+	// MaxAlertCoverageWindow is the furthest back coverage is reported
+	MaxAlertCoverageWindow = 7 * 24 * time.Hour
+	// alertCooldown is how long an alert rests after triggering before it's evaluated again
+	alertCooldown = time.Hour
+	// alertCoverageHistoryLimit caps how many recent triggers are looked at for cooldowns
+	alertCoverageHistoryLimit = 200
+)
+
+type coverageBuckets interface {
+	GetBuckets(ctx context.Context, alertID uuid.UUID, from, to time.Time) ([]models.AlertCoverageBucket, error)
+}
+
+type coverageHistory interface {
+	GetHistory(ctx context.Context, alertID *uuid.UUID, limit, offset int) ([]models.AlertHistory, error)
+}
+
+// AlertCoverageService reports whether alerts were actually being watched: how many of
+// the evaluations the schedule should have run did run, on fresh data, and the hours
+// where they didn't
+type AlertCoverageService struct {
+	buckets coverageBuckets
+	history coverageHistory
+	clock   clock.Clock
+}
+
+func NewAlertCoverageService(coverageRepo repos.AlertCoverageRepository, alertRepo repos.AlertRepository) *AlertCoverageService {
+	return &AlertCoverageService{
+		buckets: coverageRepo,
+		history: alertRepo,
+		clock:   clock.System,
+	}
+}
+
+// GetCoverage reports the alert's coverage over the window before now, or since it was
+// created if that's later
+func (s *AlertCoverageService) GetCoverage(ctx context.Context, alert *models.Alert, window time.Duration) (*models.AlertCoverage, error) {
+	if window == 0 {
+		window = DefaultAlertCoverageWindow
+	}
+	if window < time.Hour || window > MaxAlertCoverageWindow {
+		return nil, errors.BadRequest(fmt.Sprintf("Coverage window must be between 1 and %d hours", int(MaxAlertCoverageWindow/time.Hour)))
+	}
+
+	to := s.clock.Now().UTC()
+	from := to.Add(-window)
+	if alert.CreatedAt.After(from) {
+		from = alert.CreatedAt.UTC()
+	}
+
+	buckets, err := s.buckets.GetBuckets(ctx, alert.ID, from.Truncate(time.Hour), to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert coverage: %w", err)
+	}
+
+	var triggers []time.Time
+	if alert.TriggerCount > 0 {
+		history, err := s.history.GetHistory(ctx, &alert.ID, alertCoverageHistoryLimit, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get alert history: %w", err)
+		}
+		for _, h := range history {
+			if h.TriggeredAt.After(from.Add(-alertCooldown)) {
+				triggers = append(triggers, h.TriggeredAt)
+			}
+		}
+	}
+
+	return alertCoverage(from, to, buckets, triggers), nil
+}
+
+// alertCoverage works out coverage over [from, to) from the alert's hourly buckets and
+// the times it triggered. Hours too short to have expected two evaluations aren't
+// judged, so a just-created alert or the current hour doesn't show up as a gap.
+func alertCoverage(from, to time.Time, buckets []models.AlertCoverageBucket, triggers []time.Time) *models.AlertCoverage {
+	coverage := &models.AlertCoverage{From: from, To: to, Gaps: []models.AlertCoverageGap{}}
+
+	byHour := make(map[time.Time]models.AlertCoverageBucket, len(buckets))
+	for _, b := range buckets {
+		byHour[b.Bucket.UTC()] = b
+		coverage.Checks += b.Checks
+		coverage.StaleChecks += b.StaleChecks
+		coverage.FailedChecks += b.FailedChecks
+		if coverage.LastCheckedAt == nil || b.LastCheckedAt.After(*coverage.LastCheckedAt) {
+			last := b.LastCheckedAt
+			coverage.LastCheckedAt = &last
+		}
+		if b.LastDataAt != nil && (coverage.LastDataAt == nil || b.LastDataAt.After(*coverage.LastDataAt)) {
+			last := *b.LastDataAt
+			coverage.LastDataAt = &last
+		}
+	}
+
+	var resting time.Duration
+	for hour := from.Truncate(time.Hour); hour.Before(to); hour = hour.Add(time.Hour) {
+		start, end := hour, hour.Add(time.Hour)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.Sub(start) < 2*AlertEvaluationInterval {
+			continue
+		}
+
+		reason := ""
+		b := byHour[hour]
+		switch {
+		case b.Checks == 0 && coolingDown(start, end, triggers):
+			reason = models.CoverageGapCooldown
+			resting += end.Sub(start)
+		case b.Checks == 0:
+			reason = models.CoverageGapNotEvaluated
+		case b.FailedChecks == b.Checks:
+			reason = models.CoverageGapFailed
+		case b.StaleChecks+b.FailedChecks == b.Checks:
+			reason = models.CoverageGapStaleData
+		}
+		if reason == "" {
+			continue
+		}
+
+		if n := len(coverage.Gaps); n > 0 && coverage.Gaps[n-1].Reason == reason && coverage.Gaps[n-1].To.Equal(start) {
+			coverage.Gaps[n-1].To = end
+			continue
+		}
+		coverage.Gaps = append(coverage.Gaps, models.AlertCoverageGap{From: start, To: end, Reason: reason})
+	}
+
+	coverage.ExpectedChecks = int((to.Sub(from) - resting) / AlertEvaluationInterval)
+	fresh := coverage.Checks - coverage.StaleChecks - coverage.FailedChecks
+	switch {
+	case coverage.ExpectedChecks <= 0:
+		coverage.ExpectedChecks = 0
+		coverage.CoveragePercent = 100
+	default:
+		coverage.CoveragePercent = float64(fresh) / float64(coverage.ExpectedChecks) * 100
+		if coverage.CoveragePercent > 100 {
+			coverage.CoveragePercent = 100
+		}
+	}
+	return coverage
+}
+
+// coolingDown reports whether [start, end) overlaps the cooldown after any trigger
+func coolingDown(start, end time.Time, triggers []time.Time) bool {
+	for _, t := range triggers {
+		if t.Before(end) && t.Add(alertCooldown).After(start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertCoverage(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(6 * time.Hour)
+	dataAt := from.Add(5*time.Hour + 50*time.Minute)
+
+	buckets := []models.AlertCoverageBucket{
+		{Bucket: from, Checks: 12, LastCheckedAt: from.Add(55 * time.Minute)},
+		// Hour 1 wasn't evaluated at all
+		{Bucket: from.Add(2 * time.Hour), Checks: 12, StaleChecks: 12, LastCheckedAt: from.Add(2*time.Hour + 55*time.Minute)},
+		{Bucket: from.Add(3 * time.Hour), Checks: 12, FailedChecks: 12, LastCheckedAt: from.Add(3*time.Hour + 55*time.Minute)},
+		// Hour 4 rested after a trigger
+		{Bucket: from.Add(5 * time.Hour), Checks: 12, StaleChecks: 2, LastCheckedAt: from.Add(5*time.Hour + 55*time.Minute), LastDataAt: &dataAt},
+	}
+	triggers := []time.Time{from.Add(3*time.Hour + 58*time.Minute)}
+
+	coverage := alertCoverage(from, to, buckets, triggers)
+
+	assert.Equal(t, 48, coverage.Checks)
+	assert.Equal(t, 14, coverage.StaleChecks)
+	assert.Equal(t, 12, coverage.FailedChecks)
+	assert.Equal(t, 60, coverage.ExpectedChecks, "the cooldown hour isn't expected to be evaluated")
+	assert.InDelta(t, 22.0/60*100, coverage.CoveragePercent, 0.001)
+	require.NotNil(t, coverage.LastCheckedAt)
+	assert.Equal(t, from.Add(5*time.Hour+55*time.Minute), *coverage.LastCheckedAt)
+	assert.Equal(t, &dataAt, coverage.LastDataAt)
+
+	assert.Equal(t, []models.AlertCoverageGap{
+		{From: from.Add(time.Hour), To: from.Add(2 * time.Hour), Reason: models.CoverageGapNotEvaluated},
+		{From: from.Add(2 * time.Hour), To: from.Add(3 * time.Hour), Reason: models.CoverageGapStaleData},
+		{From: from.Add(3 * time.Hour), To: from.Add(4 * time.Hour), Reason: models.CoverageGapFailed},
+		{From: from.Add(4 * time.Hour), To: from.Add(5 * time.Hour), Reason: models.CoverageGapCooldown},
+	}, coverage.Gaps)
+}
+
+func TestAlertCoverage_MergesGapsAndSkipsShortHours(t *testing.T) {
+	from := time.Date(2024, 5, 1, 9, 52, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 1, 13, 3, 0, 0, time.UTC)
+
+	coverage := alertCoverage(from, to, nil, nil)
+
+	// 09:52-10:00 and 13:00-13:03 are too short to expect two evaluations
+	assert.Equal(t, []models.AlertCoverageGap{
+		{From: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), To: time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC), Reason: models.CoverageGapNotEvaluated},
+	}, coverage.Gaps)
+	assert.Zero(t, coverage.CoveragePercent)
+	assert.Nil(t, coverage.LastCheckedAt)
+}
+
+type coverageBucketsFake struct{ from time.Time }
+
+func (f *coverageBucketsFake) GetBuckets(_ context.Context, _ uuid.UUID, from, _ time.Time) ([]models.AlertCoverageBucket, error) {
+	f.from = from
+	return nil, nil
+}
+
+func TestAlertCoverageService_Window(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	buckets := &coverageBucketsFake{}
+	s := &AlertCoverageService{buckets: buckets, clock: clock.NewFake(now)}
+
+	// New alerts are only covered since they were created
+	alert := &models.Alert{ID: uuid.New(), CreatedAt: now.Add(-3*time.Hour - 30*time.Minute)}
+	coverage, err := s.GetCoverage(context.Background(), alert, 0)
+	require.NoError(t, err)
+	assert.Equal(t, alert.CreatedAt, coverage.From)
+	assert.Equal(t, now.Add(-4*time.Hour), buckets.from)
+
+	_, err = s.GetCoverage(context.Background(), alert, 8*24*time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+)
+
+// AlertEvaluator decides when alerts of one type trigger. Teams add bespoke alert types
+// by implementing it and registering it with RegisterAlertEvaluator at startup; the
+// alert evaluator job triggers an alert whenever Evaluate reports it triggered and
+// records the payload as what it triggered on.
+type AlertEvaluator interface {
+	// Type is the alert type the evaluator handles, as stored on the alert
+	Type() string
+	// Validate rejects conditions the evaluator can't work with when an alert is saved
+	Validate(conditions models.AlertConditions) error
+	Evaluate(ctx context.Context, alert *models.Alert) (triggered bool, payload map[string]interface{}, err error)
+}
+
+// AlertTrigger fires an alert with the value it triggered on
+type AlertTrigger func(ctx context.Context, alert *models.Alert, payload map[string]interface{}) error
+
+// AlertBatchEvaluator is an AlertEvaluator that evaluates a shard of alerts at once so
+// lookups they share are made once. It fires triggered alerts itself and returns how
+// many it fired.
+type AlertBatchEvaluator interface {
+	AlertEvaluator
+	EvaluateBatch(ctx context.Context, alerts []models.Alert, trigger AlertTrigger) (int, error)
+}
+
+// AlertEvaluatorRegistry maps alert types to their evaluators
+type AlertEvaluatorRegistry struct {
+	mu         sync.RWMutex
+	evaluators map[string]AlertEvaluator
+}
+
+func NewAlertEvaluatorRegistry() *AlertEvaluatorRegistry {
+	return &AlertEvaluatorRegistry{evaluators: make(map[string]AlertEvaluator)}
+}
+
+// Register adds an evaluator, refusing a second one for the same type
+func (r *AlertEvaluatorRegistry) Register(evaluator AlertEvaluator) error {
+	alertType := evaluator.Type()
+	if alertType == "" {
+		return fmt.Errorf("alert evaluator has no type")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.evaluators[alertType]; exists {
+		return fmt.Errorf("alert evaluator for %s already registered", alertType)
+	}
+	r.evaluators[alertType] = evaluator
+	return nil
+}
+
+// Get returns the evaluator for an alert type
+func (r *AlertEvaluatorRegistry) Get(alertType string) (AlertEvaluator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	evaluator, ok := r.evaluators[alertType]
+	return evaluator, ok
+}
+
+// Types lists the registered alert types in order
+func (r *AlertEvaluatorRegistry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.evaluators))
+	for alertType := range r.evaluators {
+		types = append(types, alertType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// BuiltinAlertTypes are the alert types the alert evaluator job ships with
+var BuiltinAlertTypes = []string{
+	models.AlertTypePriceAbove,
+	models.AlertTypePriceBelow,
+	models.AlertTypeLargeTransfer,
+	models.AlertTypeApproval,
+	models.AlertTypeLiquidityChange,
+	models.AlertTypeAPRChange,
+	models.AlertTypeComposite,
+	models.AlertTypeHealthFactor,
+	models.AlertTypePortfolioValue,
+	models.AlertTypePositionAPYDrop,
+	models.AlertTypeILThreshold,
+	models.AlertTypeNewMatch,
+	models.AlertTypeUnlockReminder,
+	models.AlertTypeBalanceChange,
+	models.AlertTypeBudgetExceeded,
+}
+
+// customAlertEvaluators holds the evaluators registered on top of the built-in types
+var customAlertEvaluators = NewAlertEvaluatorRegistry()
+
+// RegisterAlertEvaluator adds an evaluator for a custom alert type. Register it in both
+// the API, so alerts of the type can be created, and the worker, so they're evaluated,
+// before either starts serving.
+func RegisterAlertEvaluator(evaluator AlertEvaluator) error {
+	for _, builtin := range BuiltinAlertTypes {
+		if evaluator.Type() == builtin {
+			return fmt.Errorf("alert type %s is built in", builtin)
+		}
+	}
+	return customAlertEvaluators.Register(evaluator)
+}
+
+// LookupAlertEvaluator returns the registered evaluator for a custom alert type
+func LookupAlertEvaluator(alertType string) (AlertEvaluator, bool) {
+	return customAlertEvaluators.Get(alertType)
+}
+
+// ValidateBuiltinAlertConditions validates conditions for the built-in alert types,
+// rejecting any other type
+func ValidateBuiltinAlertConditions(alertType string, conditions models.AlertConditions) error {
+	switch alertType {
+	case models.AlertTypePriceAbove, models.AlertTypePriceBelow:
+		if conditions.Price == nil || *conditions.Price <= 0 {
+			return fmt.Errorf("price must be specified and greater than 0 for price alerts")
+		}
+	case models.AlertTypeLargeTransfer:
+		switch {
+		case conditions.Threshold != nil && conditions.ThresholdUSD != nil:
+			return fmt.Errorf("only one of threshold and thresholdUsd may be specified for transfer alerts")
+		case conditions.ThresholdUSD != nil:
+			if *conditions.ThresholdUSD <= 0 {
+				return fmt.Errorf("thresholdUsd must be greater than 0 for transfer alerts")
+			}
+		case conditions.Threshold == nil || *conditions.Threshold == "":
+			return fmt.Errorf("threshold or thresholdUsd must be specified for transfer alerts")
+		default:
+			if _, err := amounts.ParseBaseUnits(*conditions.Threshold); err != nil {
+				return fmt.Errorf("threshold must be a whole amount in wei for transfer alerts")
+			}
+		}
+	case models.AlertTypeLiquidityChange:
+		if conditions.ChangePercent == nil || *conditions.ChangePercent <= 0 {
+			return fmt.Errorf("changePercent must be specified and greater than 0 for liquidity alerts")
+		}
+	case models.AlertTypeAPRChange:
+		if conditions.MinAPR == nil && conditions.MaxAPR == nil {
+			return fmt.Errorf("either minAPR or maxAPR must be specified for APR alerts")
+		}
+		if conditions.MinAPR != nil && *conditions.MinAPR < 0 {
+			return fmt.Errorf("minAPR must be non-negative")
+		}
+		if conditions.MaxAPR != nil && *conditions.MaxAPR < 0 {
+			return fmt.Errorf("maxAPR must be non-negative")
+		}
+	case models.AlertTypeApproval:
+		// No specific conditions required for approval alerts
+	case models.AlertTypeComposite:
+		if err := validateConditionTree(conditions.Rule); err != nil {
+			return err
+		}
+	case models.AlertTypeHealthFactor:
+		if conditions.HealthFactor == nil || *conditions.HealthFactor <= 1 {
+			return fmt.Errorf("healthFactor must be specified and greater than 1 for health factor alerts")
+		}
+	case models.AlertTypePortfolioValue:
+		if conditions.ValueAbove == nil && conditions.ValueBelow == nil && conditions.ChangePercent == nil {
+			return fmt.Errorf("valueAbove, valueBelow or changePercent must be specified for portfolio value alerts")
+		}
+		if conditions.ValueAbove != nil && *conditions.ValueAbove <= 0 {
+			return fmt.Errorf("valueAbove must be greater than 0")
+		}
+		if conditions.ValueBelow != nil && *conditions.ValueBelow <= 0 {
+			return fmt.Errorf("valueBelow must be greater than 0")
+		}
+		if conditions.ChangePercent != nil && *conditions.ChangePercent <= 0 {
+			return fmt.Errorf("changePercent must be greater than 0")
+		}
+	case models.AlertTypePositionAPYDrop:
+		if conditions.APYDropPercent == nil && conditions.MinAPR == nil {
+			return fmt.Errorf("either apyDropPercent or minAPR must be specified for position APY alerts")
+		}
+		if conditions.APYDropPercent != nil && (*conditions.APYDropPercent <= 0 || *conditions.APYDropPercent > 100) {
+			return fmt.Errorf("apyDropPercent must be greater than 0 and at most 100")
+		}
+		if conditions.BaselineAPY != nil && *conditions.BaselineAPY <= 0 {
+			return fmt.Errorf("baselineAPY must be greater than 0")
+		}
+		if conditions.MinAPR != nil && *conditions.MinAPR < 0 {
+			return fmt.Errorf("minAPR must be non-negative")
+		}
+	case models.AlertTypeILThreshold:
+		if conditions.ILPercent == nil || *conditions.ILPercent <= 0 || *conditions.ILPercent >= 100 {
+			return fmt.Errorf("ilPercent must be specified and between 0 and 100 for impermanent loss alerts")
+		}
+	case models.AlertTypeNewMatch:
+		// The saved search the alert targets holds its filters
+	case models.AlertTypeUnlockReminder:
+		if conditions.DaysBefore == nil || *conditions.DaysBefore < 0 || *conditions.DaysBefore > 365 {
+			return fmt.Errorf("daysBefore must be specified and between 0 and 365 for unlock reminders")
+		}
+	case models.AlertTypeBalanceChange:
+		if conditions.ChangeUSD == nil || *conditions.ChangeUSD <= 0 {
+			return fmt.Errorf("changeUsd must be specified and greater than 0 for balance change alerts")
+		}
+		switch conditions.Direction {
+		case "", models.BalanceChangeDirectionIn, models.BalanceChangeDirectionOut:
+		default:
+			return fmt.Errorf("direction must be in or out")
+		}
+	case models.AlertTypeBudgetExceeded:
+		if !IsBudgetMetric(conditions.BudgetMetric) {
+			return fmt.Errorf("budgetMetric must be gas_spend, trading_volume or realized_loss for budget exceeded alerts")
+		}
+		if conditions.BudgetPercent != nil && *conditions.BudgetPercent <= 0 {
+			return fmt.Errorf("budgetPercent must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("unknown alert type: %s", alertType)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gasSpikeEvaluator struct{}
+
+func (gasSpikeEvaluator) Type() string { return "gas_spike" }
+
+func (gasSpikeEvaluator) Validate(conditions models.AlertConditions) error {
+	if conditions.Threshold == nil {
+		return errors.New("threshold must be specified for gas spike alerts")
+	}
+	return nil
+}
+
+func (gasSpikeEvaluator) Evaluate(context.Context, *models.Alert) (bool, map[string]interface{}, error) {
+	return false, nil, nil
+}
+
+func TestAlertEvaluatorRegistry(t *testing.T) {
+	registry := NewAlertEvaluatorRegistry()
+	require.NoError(t, registry.Register(gasSpikeEvaluator{}))
+	assert.Error(t, registry.Register(gasSpikeEvaluator{}))
+
+	evaluator, ok := registry.Get("gas_spike")
+	require.True(t, ok)
+	assert.Equal(t, "gas_spike", evaluator.Type())
+	_, ok = registry.Get("price_above")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"gas_spike"}, registry.Types())
+}
+
+func TestRegisterAlertEvaluator(t *testing.T) {
+	t.Cleanup(func() { customAlertEvaluators = NewAlertEvaluatorRegistry() })
+
+	require.NoError(t, RegisterAlertEvaluator(gasSpikeEvaluator{}))
+	s := &alertService{}
+
+	// Custom types are validated by their evaluator
+	assert.Error(t, s.validateAlertConditions("gas_spike", models.AlertConditions{}))
+	threshold := "50"
+	assert.NoError(t, s.validateAlertConditions("gas_spike", models.AlertConditions{Threshold: &threshold}))
+
+	// Built-in types can't be replaced and unknown types are still rejected
+	price := 10.0
+	assert.NoError(t, s.validateAlertConditions(models.AlertTypePriceAbove, models.AlertConditions{Price: &price}))
+	assert.Error(t, RegisterAlertEvaluator(builtinStub{models.AlertTypePriceAbove}))
+	assert.Error(t, s.validateAlertConditions("volume_spike", models.AlertConditions{}))
+}
+
+func TestValidateTransferThresholds(t *testing.T) {
+	wei, hex, fractional := "1000000000000000000", "0xde0b6b3a7640000", "1.5"
+	usd, zero := 1000.0, 0.0
+	valid := []models.AlertConditions{
+		{Threshold: &wei},
+		{Threshold: &hex},
+		{ThresholdUSD: &usd},
+	}
+	for _, conditions := range valid {
+		assert.NoError(t, ValidateBuiltinAlertConditions(models.AlertTypeLargeTransfer, conditions))
+	}
+
+	invalid := []models.AlertConditions{
+		{},
+		{Threshold: &fractional},
+		{ThresholdUSD: &zero},
+		{Threshold: &wei, ThresholdUSD: &usd},
+	}
+	for _, conditions := range invalid {
+		assert.Error(t, ValidateBuiltinAlertConditions(models.AlertTypeLargeTransfer, conditions))
+	}
+}
+
+type builtinStub struct{ alertType string }
+
+func (b builtinStub) Type() string                        { return b.alertType }
+func (builtinStub) Validate(models.AlertConditions) error { return nil }
+func (builtinStub) Evaluate(context.Context, *models.Alert) (bool, map[string]interface{}, error) {
+	return false, nil, nil
+}
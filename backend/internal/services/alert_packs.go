@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+const (
+	// AlertPackVersion is the pack format exports are written in
+	AlertPackVersion = 1
+	// maxAlertPackSize caps how many alerts a pack holds
+	maxAlertPackSize = 50
+	// maxAlertPackNameLength caps a pack's name; descriptions get ten times as much
+	maxAlertPackNameLength = 100
+)
+
+// alertPackPlaceholders names the placeholders exports leave in place of targets that
+// only mean something to the exporter
+var alertPackPlaceholders = map[string]string{
+	"address":                         "wallet",
+	models.AlertTargetTypePosition:    "position",
+	models.AlertTargetTypeSavedSearch: "saved_search",
+	models.AlertTargetTypeRewardLock:  "reward_lock",
+}
+
+// alertPackPlaceholderPattern matches a target identifier left to the importer
+var alertPackPlaceholderPattern = regexp.MustCompile(`^\{\{\s*([a-z][a-z0-9_]*)\s*\}\}$`)
+
+// AlertPackService exports users' alerts as packs others can import. Packs carry alert
+// definitions only: notification settings stay with their owner, and importers get
+// their own.
+type AlertPackService struct {
+	alerts AlertService
+}
+
+func NewAlertPackService(alertService AlertService) *AlertPackService {
+	return &AlertPackService{alerts: alertService}
+}
+
+// Export writes the user's alerts as a pack, replacing their wallets, positions, saved
+// searches and reward locks by placeholders
+func (s *AlertPackService) Export(ctx context.Context, userID uuid.UUID, req *models.ExportAlertPackRequest) (*models.AlertPack, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.BadRequest("Name is required")
+	}
+	if err := validateAlertPackText(name, req.Description); err != nil {
+		return nil, err
+	}
+	if len(req.AlertIDs) == 0 || len(req.AlertIDs) > maxAlertPackSize {
+		return nil, errors.BadRequest(fmt.Sprintf("Packs hold between 1 and %d alerts", maxAlertPackSize))
+	}
+
+	pack := &models.AlertPack{
+		Version:     AlertPackVersion,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Alerts:      make([]models.AlertPackAlert, 0, len(req.AlertIDs)),
+	}
+	placeholders := newAlertPackPlaceholders()
+	for _, alertID := range req.AlertIDs {
+		alert, err := s.alerts.GetAlert(ctx, alertID, userID)
+		if err != nil {
+			return nil, errors.NotFound("Alert")
+		}
+
+		entry := models.AlertPackAlert{
+			Type:       alert.Type,
+			Target:     placeholders.replace(alert.Target),
+			Conditions: alert.Conditions,
+			Template:   alert.Notification.Template,
+		}
+		entry.Conditions.Rule = mapRuleTargets(alert.Conditions.Rule, placeholders.replace)
+		pack.Alerts = append(pack.Alerts, entry)
+	}
+	pack.Placeholders = placeholders.names
+	return pack, nil
+}
+
+// Import creates the pack's alerts for the user. Entries the alert service rejects are
+// reported rather than failing the others.
+func (s *AlertPackService) Import(ctx context.Context, userID uuid.UUID, req *models.ImportAlertPackRequest) (*models.AlertPackImportResult, error) {
+	pack := req.Pack
+	if pack.Version < 1 || pack.Version > AlertPackVersion {
+		return nil, errors.BadRequest(fmt.Sprintf("Unsupported alert pack version %d", pack.Version))
+	}
+	if err := validateAlertPackText(pack.Name, pack.Description); err != nil {
+		return nil, err
+	}
+	if len(pack.Alerts) == 0 || len(pack.Alerts) > maxAlertPackSize {
+		return nil, errors.BadRequest(fmt.Sprintf("Packs hold between 1 and %d alerts", maxAlertPackSize))
+	}
+
+	values := make(map[string]string, len(req.Values))
+	for name, value := range req.Values {
+		values[name] = strings.TrimSpace(value)
+	}
+	// Every placeholder needs a value before anything is created
+	missing := map[string]bool{}
+	fill := func(target models.AlertTarget) models.AlertTarget {
+		match := alertPackPlaceholderPattern.FindStringSubmatch(target.Identifier)
+		if match == nil {
+			return target
+		}
+		if value := values[match[1]]; value != "" {
+			target.Identifier = value
+		} else {
+			missing[match[1]] = true
+		}
+		return target
+	}
+
+	requests := make([]*models.CreateAlertRequest, len(pack.Alerts))
+	for i, entry := range pack.Alerts {
+		notification := req.Notification
+		notification.Template = entry.Template
+		request := &models.CreateAlertRequest{
+			Type:         entry.Type,
+			Target:       fill(entry.Target),
+			Conditions:   entry.Conditions,
+			Notification: notification,
+		}
+		request.Conditions.Rule = mapRuleTargets(entry.Conditions.Rule, fill)
+		requests[i] = request
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.BadRequest("Missing values for placeholders: " + strings.Join(names, ", "))
+	}
+
+	result := &models.AlertPackImportResult{
+		Created: []*models.Alert{},
+		Failed:  []models.AlertPackImportFailure{},
+	}
+	for i, request := range requests {
+		if request.Type == "" {
+			result.Failed = append(result.Failed, models.AlertPackImportFailure{Index: i, Error: "alert type is required"})
+			continue
+		}
+		alert, err := s.alerts.CreateAlert(ctx, userID, request)
+		if err != nil {
+			message := err.Error()
+			if appErr, ok := err.(*errors.AppError); ok {
+				message = appErr.Message
+			}
+			result.Failed = append(result.Failed, models.AlertPackImportFailure{Index: i, Type: request.Type, Error: message})
+			continue
+		}
+		result.Created = append(result.Created, alert)
+	}
+	return result, nil
+}
+
+func validateAlertPackText(name, description string) error {
+	if utf8.RuneCountInString(name) > maxAlertPackNameLength {
+		return errors.BadRequest(fmt.Sprintf("Name must be at most %d characters", maxAlertPackNameLength))
+	}
+	if utf8.RuneCountInString(description) > 10*maxAlertPackNameLength {
+		return errors.BadRequest(fmt.Sprintf("Description must be at most %d characters", 10*maxAlertPackNameLength))
+	}
+	return nil
+}
+
+// alertPackPlaceholderSet hands out placeholders during an export, the same one for every
+// alert with the same target
+type alertPackPlaceholderSet struct {
+	byTarget map[string]string
+	counts   map[string]int
+	names    []string
+}
+
+func newAlertPackPlaceholders() *alertPackPlaceholderSet {
+	return &alertPackPlaceholderSet{
+		byTarget: make(map[string]string),
+		counts:   make(map[string]int),
+	}
+}
+
+// replace returns target with its identifier replaced by a placeholder when it's
+// personal to the exporter
+func (p *alertPackPlaceholderSet) replace(target models.AlertTarget) models.AlertTarget {
+	base, ok := alertPackPlaceholders[target.Type]
+	if !ok || target.Identifier == "" {
+		return target
+	}
+
+	key := target.Type + ":" + strings.ToLower(target.Identifier)
+	name, seen := p.byTarget[key]
+	if !seen {
+		p.counts[base]++
+		name = base
+		if p.counts[base] > 1 {
+			name = fmt.Sprintf("%s_%d", base, p.counts[base])
+		}
+		p.byTarget[key] = name
+		p.names = append(p.names, name)
+	}
+	target.Identifier = "{{" + name + "}}"
+	return target
+}
+
+// mapRuleTargets copies a composite condition tree with fn applied to the targets its
+// leaves set
+func mapRuleTargets(node *models.AlertConditionNode, fn func(models.AlertTarget) models.AlertTarget) *models.AlertConditionNode {
+	if node == nil {
+		return nil
+	}
+
+	copied := *node
+	if node.Target != nil {
+		target := fn(*node.Target)
+		copied.Target = &target
+	}
+	if node.Children != nil {
+		copied.Children = make([]models.AlertConditionNode, len(node.Children))
+		for i := range node.Children {
+			copied.Children[i] = *mapRuleTargets(&node.Children[i], fn)
+		}
+	}
+	return &copied
+}
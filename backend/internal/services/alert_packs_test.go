@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packAlerts serves the user's alerts by ID and records the alerts created, rejecting
+// ones without conditions
+type packAlerts struct {
+	AlertService
+	alerts  map[uuid.UUID]*models.Alert
+	created []*models.CreateAlertRequest
+}
+
+func (a *packAlerts) GetAlert(_ context.Context, alertID, _ uuid.UUID) (*models.Alert, error) {
+	alert, ok := a.alerts[alertID]
+	if !ok {
+		return nil, repos.ErrAlertNotFound
+	}
+	return alert, nil
+}
+
+func (a *packAlerts) CreateAlert(_ context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.Alert, error) {
+	if req.Conditions == (models.AlertConditions{}) {
+		return nil, fmt.Errorf("invalid alert conditions: none given")
+	}
+	a.created = append(a.created, req)
+	return &models.Alert{ID: uuid.New(), UserID: userID, Type: req.Type, Target: req.Target, Conditions: req.Conditions, Notification: req.Notification}, nil
+}
+
+func TestAlertPackExportReplacesPersonalTargets(t *testing.T) {
+	price, change := 2000.0, 5000.0
+	wallet := "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+	priceAlert := &models.Alert{
+		ID:           uuid.New(),
+		Type:         models.AlertTypePriceAbove,
+		Target:       models.AlertTarget{Type: "token", Identifier: "0xa0b8", ChainID: 1},
+		Conditions:   models.AlertConditions{Price: &price},
+		Notification: models.AlertNotification{Email: true, Webhook: "https://example.com/hook", Template: "{{token.symbol}} at {{price}}"},
+	}
+	walletAlert := &models.Alert{
+		ID:         uuid.New(),
+		Type:       models.AlertTypeBalanceChange,
+		Target:     models.AlertTarget{Type: "address", Identifier: wallet},
+		Conditions: models.AlertConditions{ChangeUSD: &change},
+	}
+	sameWalletAlert := &models.Alert{
+		ID:         uuid.New(),
+		Type:       models.AlertTypeLargeTransfer,
+		Target:     models.AlertTarget{Type: "address", Identifier: "0x742D35CC6634C0532925A3B844BC454E4438F44E"},
+		Conditions: models.AlertConditions{ChangeUSD: &change},
+	}
+	otherWalletAlert := &models.Alert{
+		ID:         uuid.New(),
+		Type:       models.AlertTypeBalanceChange,
+		Target:     models.AlertTarget{Type: "address", Identifier: "0x1111111111111111111111111111111111111111"},
+		Conditions: models.AlertConditions{ChangeUSD: &change},
+	}
+	alerts := &packAlerts{alerts: map[uuid.UUID]*models.Alert{}}
+	for _, alert := range []*models.Alert{priceAlert, walletAlert, sameWalletAlert, otherWalletAlert} {
+		alerts.alerts[alert.ID] = alert
+	}
+	s := NewAlertPackService(alerts)
+
+	pack, err := s.Export(context.Background(), uuid.New(), &models.ExportAlertPackRequest{
+		AlertIDs: []uuid.UUID{priceAlert.ID, walletAlert.ID, sameWalletAlert.ID, otherWalletAlert.ID},
+		Name:     " Whale watch ",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, AlertPackVersion, pack.Version)
+	assert.Equal(t, "Whale watch", pack.Name)
+	assert.Equal(t, []string{"wallet", "wallet_2"}, pack.Placeholders)
+	require.Len(t, pack.Alerts, 4)
+	assert.Equal(t, "0xa0b8", pack.Alerts[0].Target.Identifier)
+	assert.Equal(t, "{{token.symbol}} at {{price}}", pack.Alerts[0].Template)
+	assert.Equal(t, "{{wallet}}", pack.Alerts[1].Target.Identifier)
+	assert.Equal(t, "{{wallet}}", pack.Alerts[2].Target.Identifier)
+	assert.Equal(t, "{{wallet_2}}", pack.Alerts[3].Target.Identifier)
+	// The exporter's own alert is left as it was
+	assert.Equal(t, wallet, walletAlert.Target.Identifier)
+
+	_, err = s.Export(context.Background(), uuid.New(), &models.ExportAlertPackRequest{AlertIDs: []uuid.UUID{uuid.New()}, Name: "Missing"})
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 404, appErr.Status)
+}
+
+func TestAlertPackImportFillsPlaceholders(t *testing.T) {
+	price, change := 2000.0, 5000.0
+	gas := 20.0
+	pack := models.AlertPack{
+		Version: AlertPackVersion,
+		Name:    "Whale watch",
+		Alerts: []models.AlertPackAlert{
+			{Type: models.AlertTypePriceAbove, Target: models.AlertTarget{Type: "token", Identifier: "0xa0b8", ChainID: 1}, Conditions: models.AlertConditions{Price: &price}, Template: "{{price}}"},
+			{Type: models.AlertTypeBalanceChange, Target: models.AlertTarget{Type: "address", Identifier: "{{ wallet }}"}, Conditions: models.AlertConditions{ChangeUSD: &change}},
+			{Type: models.AlertTypeComposite, Conditions: models.AlertConditions{Rule: &models.AlertConditionNode{
+				Operator: models.ConditionOperatorAnd,
+				Children: []models.AlertConditionNode{{Metric: models.ConditionMetricGasPriceGwei, Comparator: models.ConditionComparatorLT, Value: &gas, Target: &models.AlertTarget{Type: "address", Identifier: "{{wallet}}"}}},
+			}}},
+			// Rejected by the alert service
+			{Type: models.AlertTypePriceBelow, Target: models.AlertTarget{Type: "token", Identifier: "0xa0b8", ChainID: 1}},
+		},
+	}
+	alerts := &packAlerts{}
+	s := NewAlertPackService(alerts)
+
+	_, err := s.Import(context.Background(), uuid.New(), &models.ImportAlertPackRequest{Pack: pack})
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "Missing values for placeholders: wallet", appErr.Message)
+	assert.Empty(t, alerts.created, "nothing is created while placeholders are missing")
+
+	result, err := s.Import(context.Background(), uuid.New(), &models.ImportAlertPackRequest{
+		Pack:         pack,
+		Values:       map[string]string{"wallet": " 0xabc "},
+		Notification: models.AlertNotification{InApp: true},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Created, 3)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, 3, result.Failed[0].Index)
+	assert.Contains(t, result.Failed[0].Error, "invalid alert conditions")
+
+	assert.Equal(t, models.AlertNotification{InApp: true, Template: "{{price}}"}, alerts.created[0].Notification)
+	assert.Equal(t, "0xabc", alerts.created[1].Target.Identifier)
+	assert.Equal(t, "0xabc", alerts.created[2].Conditions.Rule.Children[0].Target.Identifier)
+	// The pack itself is left with its placeholders
+	assert.Equal(t, "{{wallet}}", pack.Alerts[2].Conditions.Rule.Children[0].Target.Identifier)
+
+	_, err = s.Import(context.Background(), uuid.New(), &models.ImportAlertPackRequest{Pack: models.AlertPack{Version: 2, Alerts: pack.Alerts}})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.Status)
+}
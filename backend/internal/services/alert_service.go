@@ -2,11 +2,18 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/netguard"
 	"github.com/google/uuid"
 )
 
@@ -18,17 +25,48 @@ type AlertService interface {
 	DeleteAlert(ctx context.Context, alertID uuid.UUID, userID uuid.UUID) error
 	GetAlertHistory(ctx context.Context, alertID *uuid.UUID, userID uuid.UUID, limit, offset int) ([]models.AlertHistory, error)
 	TriggerAlert(ctx context.Context, alertID uuid.UUID, triggeredValue map[string]interface{}) error
+	MuteAlert(ctx context.Context, alertID uuid.UUID, userID uuid.UUID, until *time.Time) (*models.Alert, error)
+	GetAlertStats(ctx context.Context, userID uuid.UUID, period string) (*models.AlertStats, error)
 }
 
+// noisiestAlertsLimit caps how many alerts are ranked in alert stats
+const noisiestAlertsLimit = 10
+
+// ErrInvalidStatsPeriod is returned by GetAlertStats for a period other than 24h, 7d or 30d
+var ErrInvalidStatsPeriod = stderrors.New("invalid period")
+
 type alertService struct {
 	alertRepo repos.AlertRepository
 	userRepo  repos.UserRepository
+	outbox    NotificationOutbox
+	// webhookPolicy decides which webhook URLs alerts can be given
+	webhookPolicy netguard.Policy
+	clock         clock.Clock
 }
 
 func NewAlertService(alertRepo repos.AlertRepository, userRepo repos.UserRepository) AlertService {
+	return NewAlertServiceWithOutbox(alertRepo, userRepo, nil)
+}
+
+// NewAlertServiceWithOutbox creates an alert service that sends notifications when alerts
+// trigger. Without an outbox triggers are still queued and left for the outbox job.
+func NewAlertServiceWithOutbox(alertRepo repos.AlertRepository, userRepo repos.UserRepository, outbox NotificationOutbox) AlertService {
 	return &alertService{
 		alertRepo: alertRepo,
 		userRepo:  userRepo,
+		outbox:    outbox,
+		clock:     clock.System,
+	}
+}
+
+// NewAlertServiceWithWebhookPolicy creates an alert service accepting the webhook URLs
+// the policy allows, rather than only https URLs of public hosts
+func NewAlertServiceWithWebhookPolicy(alertRepo repos.AlertRepository, userRepo repos.UserRepository, policy netguard.Policy) AlertService {
+	return &alertService{
+		alertRepo:     alertRepo,
+		userRepo:      userRepo,
+		webhookPolicy: policy,
+		clock:         clock.System,
 	}
 }
 
@@ -43,6 +81,53 @@ func (s *alertService) CreateAlert(ctx context.Context, userID uuid.UUID, req *m
 	if err := s.validateAlertConditions(req.Type, req.Conditions); err != nil {
 		return nil, fmt.Errorf("invalid alert conditions: %w", err)
 	}
+	if err := validateConditionTargets(req.Conditions.Rule, req.Target); err != nil {
+		return nil, fmt.Errorf("invalid alert conditions: %w", err)
+	}
+	if req.Type == models.AlertTypePortfolioValue || req.Type == models.AlertTypeBudgetExceeded {
+		// Portfolio value and budget alerts always watch the owner's whole portfolio
+		req.Target = models.AlertTarget{Type: models.AlertTargetTypePortfolio}
+	}
+	if req.Type == models.AlertTypePositionAPYDrop || req.Type == models.AlertTypeILThreshold {
+		if req.Target.Type != models.AlertTargetTypePosition {
+			return nil, fmt.Errorf("invalid alert target: %s alerts must target a position", req.Type)
+		}
+		if _, err := uuid.Parse(req.Target.Identifier); err != nil {
+			return nil, fmt.Errorf("invalid alert target: position identifier must be a position ID")
+		}
+	}
+	if req.Type == models.AlertTypeNewMatch {
+		if req.Target.Type != models.AlertTargetTypeSavedSearch {
+			return nil, fmt.Errorf("invalid alert target: %s alerts must target a saved search", req.Type)
+		}
+		if _, err := uuid.Parse(req.Target.Identifier); err != nil {
+			return nil, fmt.Errorf("invalid alert target: saved search identifier must be a saved search ID")
+		}
+	}
+	if req.Type == models.AlertTypeUnlockReminder {
+		// Reminders cover all the owner's locks unless they target one
+		if req.Target.Type != models.AlertTargetTypeRewardLock {
+			req.Target = models.AlertTarget{Type: models.AlertTargetTypePortfolio}
+		} else if _, err := uuid.Parse(req.Target.Identifier); err != nil {
+			return nil, fmt.Errorf("invalid alert target: reward lock identifier must be a reward lock ID")
+		}
+	}
+	if req.Type == models.AlertTypeBalanceChange {
+		if req.Target.Type != "address" {
+			return nil, fmt.Errorf("invalid alert target: %s alerts must target a wallet address", req.Type)
+		}
+		address, err := addr.Parse(req.Target.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert target: %w", err)
+		}
+		req.Target.Identifier = address
+	}
+	if err := req.Target.ResolveAsset(); err != nil {
+		return nil, fmt.Errorf("invalid alert target: %w", err)
+	}
+	if err := s.validateNotification(ctx, &req.Notification); err != nil {
+		return nil, err
+	}
 
 	alert := &models.Alert{
 		ID:           uuid.New(),
@@ -70,7 +155,7 @@ func (s *alertService) GetAlert(ctx context.Context, alertID uuid.UUID, userID u
 
 	// Verify ownership
 	if alert.UserID != userID {
-		return nil, fmt.Errorf("alert not found")
+		return nil, repos.ErrAlertNotFound
 	}
 
 	return alert, nil
@@ -122,9 +207,15 @@ func (s *alertService) UpdateAlert(ctx context.Context, alertID uuid.UUID, userI
 		if err := s.validateAlertConditions(alert.Type, *req.Conditions); err != nil {
 			return nil, fmt.Errorf("invalid alert conditions: %w", err)
 		}
+		if err := validateConditionTargets(req.Conditions.Rule, alert.Target); err != nil {
+			return nil, fmt.Errorf("invalid alert conditions: %w", err)
+		}
 		alert.Conditions = *req.Conditions
 	}
 	if req.Notification != nil {
+		if err := s.validateNotification(ctx, req.Notification); err != nil {
+			return nil, err
+		}
 		alert.Notification = *req.Notification
 	}
 
@@ -197,61 +288,97 @@ func (s *alertService) TriggerAlert(ctx context.Context, alertID uuid.UUID, trig
 		return fmt.Errorf("failed to get alert: %w", err)
 	}
 
-	// Update alert as triggered
-	if err := s.alertRepo.UpdateTriggered(ctx, alertID); err != nil {
-		return fmt.Errorf("failed to update alert: %w", err)
-	}
-
 	// Create history record
 	history := &models.AlertHistory{
 		ID:                 uuid.New(),
 		AlertID:            alertID,
-		TriggeredAt:        time.Now(),
+		TriggeredAt:        s.clock.Now(),
 		ConditionsSnapshot: alert.Conditions,
 		TriggeredValue:     triggeredValue,
-		NotificationSent:   false, // TODO: Implement notification sending
+		NotificationSent:   false,
 	}
 
-	if err := s.alertRepo.CreateHistory(ctx, history); err != nil {
-		return fmt.Errorf("failed to create alert history: %w", err)
+	// The trigger, its history and the pending notification are written together, so a
+	// crash after this point can only delay the notification, never lose it
+	outboxID, err := s.alertRepo.RecordTrigger(ctx, history, alert.Notification, false)
+	if err != nil {
+		return fmt.Errorf("failed to record alert trigger: %w", err)
 	}
 
-	// TODO: Send notifications (email, webhook)
-	// This would be implemented based on alert.Notification preferences
+	// Sending now is best effort; anything left pending is retried by the outbox job
+	if s.outbox != nil {
+		if err := s.outbox.Dispatch(ctx, outboxID); err != nil {
+			logger.Warn("Failed to dispatch alert notification", "alertId", alertID, "error", err)
+		}
+	}
 
 	return nil
 }
 
-// validateAlertConditions validates that the conditions are appropriate for the alert type
-func (s *alertService) validateAlertConditions(alertType string, conditions models.AlertConditions) error {
-	switch alertType {
-	case models.AlertTypePriceAbove, models.AlertTypePriceBelow:
-		if conditions.Price == nil || *conditions.Price <= 0 {
-			return fmt.Errorf("price must be specified and greater than 0 for price alerts")
-		}
-	case models.AlertTypeLargeTransfer:
-		if conditions.Threshold == nil || *conditions.Threshold == "" {
-			return fmt.Errorf("threshold must be specified for transfer alerts")
-		}
-	case models.AlertTypeLiquidityChange:
-		if conditions.ChangePercent == nil || *conditions.ChangePercent <= 0 {
-			return fmt.Errorf("changePercent must be specified and greater than 0 for liquidity alerts")
-		}
-	case models.AlertTypeAPRChange:
-		if conditions.MinAPR == nil && conditions.MaxAPR == nil {
-			return fmt.Errorf("either minAPR or maxAPR must be specified for APR alerts")
-		}
-		if conditions.MinAPR != nil && *conditions.MinAPR < 0 {
-			return fmt.Errorf("minAPR must be non-negative")
-		}
-		if conditions.MaxAPR != nil && *conditions.MaxAPR < 0 {
-			return fmt.Errorf("maxAPR must be non-negative")
-		}
-	case models.AlertTypeApproval:
-		// No specific conditions required for approval alerts
+// MuteAlert silences notifications for an alert until the given time; nil unmutes it
+func (s *alertService) MuteAlert(ctx context.Context, alertID uuid.UUID, userID uuid.UUID, until *time.Time) (*models.Alert, error) {
+	// Verify ownership first
+	alert, err := s.GetAlert(ctx, alertID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.alertRepo.SetMutedUntil(ctx, alertID, until); err != nil {
+		return nil, fmt.Errorf("failed to mute alert: %w", err)
+	}
+
+	alert.MutedUntil = until
+	return alert, nil
+}
+
+// GetAlertStats returns trigger and delivery analytics for the user's alerts over
+// period, one of 24h, 7d or 30d (default 7d)
+func (s *alertService) GetAlertStats(ctx context.Context, userID uuid.UUID, period string) (*models.AlertStats, error) {
+	var window time.Duration
+	bucket := "day"
+	switch period {
+	case "24h":
+		window = 24 * time.Hour
+		bucket = "hour"
+	case "", "7d":
+		period = "7d"
+		window = 7 * 24 * time.Hour
+	case "30d":
+		window = 30 * 24 * time.Hour
 	default:
-		return fmt.Errorf("unknown alert type: %s", alertType)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatsPeriod, period)
 	}
 
+	stats, err := s.alertRepo.GetStats(ctx, userID, s.clock.Now().Add(-window), bucket, noisiestAlertsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert stats: %w", err)
+	}
+	stats.Period = period
+
+	return stats, nil
+}
+
+// validateNotification checks the webhook URLs an alert is given can be posted to.
+// Generic webhooks still have to be verified before anything is delivered to them.
+func (s *alertService) validateNotification(ctx context.Context, notification *models.AlertNotification) error {
+	if notification.Webhook = strings.TrimSpace(notification.Webhook); notification.Webhook != "" {
+		if err := checkWebhookURL(ctx, s.webhookPolicy, notification.Webhook); err != nil {
+			return err
+		}
+	}
+	if notification.Discord = strings.TrimSpace(notification.Discord); notification.Discord != "" && !isDiscordWebhookURL(notification.Discord) {
+		return errors.BadRequest("Discord notifications need a Discord channel webhook URL")
+	}
+	if err := ValidateNotificationTemplate(notification.Template); err != nil {
+		return errors.BadRequest(err.Error())
+	}
 	return nil
+}
+
+// validateAlertConditions validates that the conditions are appropriate for the alert type
+func (s *alertService) validateAlertConditions(alertType string, conditions models.AlertConditions) error {
+	if evaluator, ok := LookupAlertEvaluator(alertType); ok {
+		return evaluator.Validate(conditions)
+	}
+	return ValidateBuiltinAlertConditions(alertType, conditions)
 }
\ No newline at end of file
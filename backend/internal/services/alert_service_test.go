@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -52,38 +53,40 @@ func (m *MockAlertRepository) UpdateTriggered(ctx context.Context, alertID uuid.
 	return args.Error(0)
 }
 
-func (m *MockAlertRepository) CreateHistory(ctx context.Context, history *models.AlertHistory) error {
-	args := m.Called(ctx, history)
+func (m *MockAlertRepository) SetMutedUntil(ctx context.Context, alertID uuid.UUID, mutedUntil *time.Time) error {
+	args := m.Called(ctx, alertID, mutedUntil)
 	return args.Error(0)
 }
 
-func (m *MockAlertRepository) GetHistory(ctx context.Context, alertID *uuid.UUID, limit, offset int) ([]models.AlertHistory, error) {
-	args := m.Called(ctx, alertID, limit, offset)
-	return args.Get(0).([]models.AlertHistory), args.Error(1)
-}
-
-type MockUserRepository struct {
-	mock.Mock
+func (m *MockAlertRepository) GetStats(ctx context.Context, userID uuid.UUID, since time.Time, bucket string, noisiestLimit int) (*models.AlertStats, error) {
+	args := m.Called(ctx, userID, since, bucket, noisiestLimit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AlertStats), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*models.User), args.Error(1)
+func (m *MockAlertRepository) GetTriggersSince(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]models.TriggeredAlert, error) {
+	args := m.Called(ctx, userID, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TriggeredAlert), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByAddress(ctx context.Context, address string) (*models.User, error) {
-	args := m.Called(ctx, address)
-	return args.Get(0).(*models.User), args.Error(1)
+func (m *MockAlertRepository) CreateHistory(ctx context.Context, history *models.AlertHistory) error {
+	args := m.Called(ctx, history)
+	return args.Error(0)
 }
 
-func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(0)
+func (m *MockAlertRepository) RecordTrigger(ctx context.Context, history *models.AlertHistory, notification models.AlertNotification, urgent bool) (uuid.UUID, error) {
+	args := m.Called(ctx, history, notification, urgent)
+	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
-	args := m.Called(ctx, user)
-	return args.Error(0)
+func (m *MockAlertRepository) GetHistory(ctx context.Context, alertID *uuid.UUID, limit, offset int) ([]models.AlertHistory, error) {
+	args := m.Called(ctx, alertID, limit, offset)
+	return args.Get(0).([]models.AlertHistory), args.Error(1)
 }
 
 func TestAlertService_CreateAlert(t *testing.T) {
@@ -187,6 +190,45 @@ func TestAlertService_CreateAlert_ValidationErrors(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "either minAPR or maxAPR must be specified")
 	})
+
+	t.Run("Missing portfolio value conditions", func(t *testing.T) {
+		req := &models.CreateAlertRequest{
+			Type:       models.AlertTypePortfolioValue,
+			Conditions: models.AlertConditions{},
+		}
+
+		mockUserRepo.On("GetByID", ctx, userID).Return(user, nil)
+
+		_, err := service.CreateAlert(ctx, userID, req)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "valueAbove, valueBelow or changePercent must be specified")
+	})
+}
+
+func TestAlertService_CreateAlert_PortfolioValue(t *testing.T) {
+	ctx := context.Background()
+
+	mockAlertRepo := new(MockAlertRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewAlertService(mockAlertRepo, mockUserRepo)
+
+	userID := uuid.New()
+	below := 250000.0
+	req := &models.CreateAlertRequest{
+		Type:         models.AlertTypePortfolioValue,
+		Target:       models.AlertTarget{Type: "token", Identifier: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", ChainID: 1},
+		Conditions:   models.AlertConditions{ValueBelow: &below},
+		Notification: models.AlertNotification{Email: true},
+	}
+
+	mockUserRepo.On("GetByID", ctx, userID).Return(&models.User{ID: userID}, nil)
+	mockAlertRepo.On("Create", ctx, mock.AnythingOfType("*models.Alert")).Return(nil)
+
+	alert, err := service.CreateAlert(ctx, userID, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertTarget{Type: models.AlertTargetTypePortfolio}, alert.Target)
+	assert.Equal(t, below, *alert.Conditions.ValueBelow)
 }
 
 func TestAlertService_GetAlert(t *testing.T) {
@@ -303,6 +345,32 @@ func TestAlertService_UpdateAlert(t *testing.T) {
 	mockAlertRepo.AssertExpectations(t)
 }
 
+func TestAlertService_MuteAlert_NotFound(t *testing.T) {
+	ctx := context.Background()
+
+	mockAlertRepo := new(MockAlertRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewAlertService(mockAlertRepo, mockUserRepo)
+
+	userID := uuid.New()
+	othersAlert := &models.Alert{ID: uuid.New(), UserID: uuid.New(), Type: models.AlertTypePriceAbove}
+	deletedAlert := &models.Alert{ID: uuid.New(), UserID: userID, Type: models.AlertTypePriceAbove}
+	until := time.Now().Add(time.Hour)
+
+	mockAlertRepo.On("GetByID", ctx, othersAlert.ID).Return(othersAlert, nil)
+	// Deleted between the ownership check and the update
+	mockAlertRepo.On("GetByID", ctx, deletedAlert.ID).Return(deletedAlert, nil)
+	mockAlertRepo.On("SetMutedUntil", ctx, deletedAlert.ID, &until).Return(repos.ErrAlertNotFound)
+
+	_, err := service.MuteAlert(ctx, othersAlert.ID, userID, &until)
+	assert.ErrorIs(t, err, repos.ErrAlertNotFound)
+
+	_, err = service.MuteAlert(ctx, deletedAlert.ID, userID, &until)
+	assert.ErrorIs(t, err, repos.ErrAlertNotFound)
+
+	mockAlertRepo.AssertExpectations(t)
+}
+
 func TestAlertService_TriggerAlert(t *testing.T) {
 	ctx := context.Background()
 	
@@ -330,8 +398,7 @@ func TestAlertService_TriggerAlert(t *testing.T) {
 
 	// Setup mocks
 	mockAlertRepo.On("GetByID", ctx, alertID).Return(alert, nil)
-	mockAlertRepo.On("UpdateTriggered", ctx, alertID).Return(nil)
-	mockAlertRepo.On("CreateHistory", ctx, mock.AnythingOfType("*models.AlertHistory")).Return(nil)
+	mockAlertRepo.On("RecordTrigger", ctx, mock.AnythingOfType("*models.AlertHistory"), alert.Notification, false).Return(uuid.New(), nil)
 
 	// Execute test
 	err := service.TriggerAlert(ctx, alertID, triggeredValue)
@@ -420,4 +487,30 @@ func TestAlertService_GetUserAlerts(t *testing.T) {
 
 	// Verify mocks
 	mockAlertRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+func TestAlertService_GetAlertStats(t *testing.T) {
+	ctx := context.Background()
+
+	mockAlertRepo := new(MockAlertRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewAlertService(mockAlertRepo, mockUserRepo)
+
+	userID := uuid.New()
+
+	// Setup mocks
+	mockAlertRepo.On("GetStats", ctx, userID, mock.AnythingOfType("time.Time"), "hour", 10).Return(&models.AlertStats{}, nil)
+
+	// Execute test
+	stats, err := service.GetAlertStats(ctx, userID, "24h")
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, "24h", stats.Period)
+
+	_, err = service.GetAlertStats(ctx, userID, "1y")
+	assert.ErrorIs(t, err, ErrInvalidStatsPeriod)
+	assert.EqualError(t, err, "invalid period: 1y")
+
+	// Verify mocks
+	mockAlertRepo.AssertExpectations(t)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// AlertTargetService lets users re-point the alerts expired because their pool or
+// token disappeared to a successor, keeping the alert's conditions and history
+type AlertTargetService struct {
+	alerts     AlertService
+	targetRepo repos.AlertTargetRepository
+	goneAfter  time.Duration
+	clock      clock.Clock
+}
+
+func NewAlertTargetService(alertService AlertService, targetRepo repos.AlertTargetRepository, goneAfter time.Duration) *AlertTargetService {
+	return &AlertTargetService{
+		alerts:     alertService,
+		targetRepo: targetRepo,
+		goneAfter:  goneAfter,
+		clock:      clock.System,
+	}
+}
+
+// GetSuccessors suggests the live pools or tokens an alert expired for its target
+// could be re-pointed to, most likely first
+func (s *AlertTargetService) GetSuccessors(ctx context.Context, userID, alertID uuid.UUID) ([]*models.AlertTargetSuccessor, error) {
+	alert, err := s.expiredAlert(ctx, userID, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	successors, err := s.targetRepo.GetSuccessors(ctx, alert.Target, s.clock.Now().Add(-s.goneAfter))
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return successors, nil
+}
+
+// Repoint moves an alert expired for its target to a live pool or token of the same
+// kind and makes it active again
+func (s *AlertTargetService) Repoint(ctx context.Context, userID, alertID uuid.UUID, req *models.RepointAlertRequest) (*models.Alert, error) {
+	alert, err := s.expiredAlert(ctx, userID, alertID)
+	if err != nil {
+		return nil, err
+	}
+
+	target := req.Target
+	if target.Type == "" {
+		target.Type = alert.Target.Type
+	}
+	if target.Type != alert.Target.Type {
+		return nil, errors.BadRequest("Alert must be re-pointed to another " + alert.Target.Type)
+	}
+	if target.Identifier == "" {
+		return nil, errors.BadRequest("Target identifier is required")
+	}
+	if target.Type == "token" && target.ChainID == 0 {
+		target.ChainID = alert.Target.ChainID
+	}
+	target.Asset = nil
+	if err := target.ResolveAsset(); err != nil {
+		return nil, errors.BadRequest("Invalid alert target: " + err.Error())
+	}
+
+	live, err := s.targetRepo.IsLive(ctx, target, s.clock.Now().Add(-s.goneAfter))
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if !live {
+		return nil, errors.BadRequest("Alert target is not live")
+	}
+
+	if err := s.targetRepo.Repoint(ctx, alertID, target); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	repointed, err := s.alerts.GetAlert(ctx, alertID, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return repointed, nil
+}
+
+// expiredAlert returns one of the user's alerts expired because its target is gone
+func (s *AlertTargetService) expiredAlert(ctx context.Context, userID, alertID uuid.UUID) (*models.Alert, error) {
+	alert, err := s.alerts.GetAlert(ctx, alertID, userID)
+	if err != nil {
+		return nil, errors.NotFound("Alert")
+	}
+	if alert.Status != models.AlertStatusExpired || alert.ExpiredReason == nil {
+		return nil, errors.BadRequest("Alert was not expired for its target")
+	}
+	return alert, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ownedAlerts keeps alerts in memory, finding only those of their owner
+type ownedAlerts struct {
+	AlertService
+	alerts map[uuid.UUID]*models.Alert
+}
+
+func (a *ownedAlerts) GetAlert(_ context.Context, alertID, userID uuid.UUID) (*models.Alert, error) {
+	alert, ok := a.alerts[alertID]
+	if !ok || alert.UserID != userID {
+		return nil, repos.ErrAlertNotFound
+	}
+	copied := *alert
+	return &copied, nil
+}
+
+// liveTargets repoints the alerts of ownedAlerts to the targets it knows are live
+type liveTargets struct {
+	repos.AlertTargetRepository
+	alerts *ownedAlerts
+	live   map[string]bool
+}
+
+func (r *liveTargets) IsLive(_ context.Context, target models.AlertTarget, _ time.Time) (bool, error) {
+	return r.live[target.Type+":"+target.Identifier], nil
+}
+
+func (r *liveTargets) Repoint(_ context.Context, alertID uuid.UUID, target models.AlertTarget) error {
+	alert := r.alerts.alerts[alertID]
+	alert.Target = target
+	alert.Status = models.AlertStatusActive
+	alert.ExpiredReason = nil
+	return nil
+}
+
+func TestAlertTargetServiceRepoint(t *testing.T) {
+	userID := uuid.New()
+	reason := models.AlertExpiredPoolDeactivated
+	expired := &models.Alert{ID: uuid.New(), UserID: userID, Type: models.AlertTypeAPRChange, Status: models.AlertStatusExpired,
+		Target: models.AlertTarget{Type: "pool", Identifier: "pool-v2"}, ExpiredReason: &reason}
+	active := &models.Alert{ID: uuid.New(), UserID: userID, Type: models.AlertTypeAPRChange, Status: models.AlertStatusActive,
+		Target: models.AlertTarget{Type: "pool", Identifier: "pool-v2"}}
+	alerts := &ownedAlerts{alerts: map[uuid.UUID]*models.Alert{expired.ID: expired, active.ID: active}}
+	service := NewAlertTargetService(alerts, &liveTargets{alerts: alerts, live: map[string]bool{"pool:pool-v3": true}}, time.Hour)
+	ctx := context.Background()
+
+	repoint := func(alertID, userID uuid.UUID, target models.AlertTarget) (*models.Alert, error) {
+		return service.Repoint(ctx, userID, alertID, &models.RepointAlertRequest{Target: target})
+	}
+	assertStatus := func(err error, status int) {
+		t.Helper()
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok, "%v", err)
+		assert.Equal(t, status, appErr.Status)
+	}
+
+	_, err := repoint(expired.ID, uuid.New(), models.AlertTarget{Identifier: "pool-v3"})
+	assertStatus(err, 404)
+	_, err = repoint(active.ID, userID, models.AlertTarget{Identifier: "pool-v3"})
+	assertStatus(err, 400)
+	_, err = repoint(expired.ID, userID, models.AlertTarget{Type: "token", Identifier: "0xabc", ChainID: 1})
+	assertStatus(err, 400)
+	_, err = repoint(expired.ID, userID, models.AlertTarget{Identifier: "pool-v1"})
+	assertStatus(err, 400)
+
+	alert, err := repoint(expired.ID, userID, models.AlertTarget{Identifier: "pool-v3"})
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertStatusActive, alert.Status)
+	assert.Equal(t, models.AlertTarget{Type: "pool", Identifier: "pool-v3"}, alert.Target)
+	assert.Nil(t, alert.ExpiredReason)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// maxAllowanceChecks caps how many allowances one verification reads
+const maxAllowanceChecks = 200
+
+// AllowanceService checks stored allowances against the chain, so a revoke shows up
+// straight away instead of on the next sync
+type AllowanceService struct {
+	walletRepo    repos.WalletRepository
+	allowanceRepo repos.AllowanceRepository
+	// readAllowances reads allowances on chain through Multicall; replaced in tests
+	readAllowances func(ctx context.Context, alchemyAPIKey string, chainID int, owner string, queries []blockchain.AllowanceQuery) ([]*big.Int, error)
+	clock          clock.Clock
+	// contracts describes the spenders; may be nil
+	contracts *ContractService
+}
+
+func NewAllowanceService(walletRepo repos.WalletRepository, allowanceRepo repos.AllowanceRepository) *AllowanceService {
+	return &AllowanceService{
+		walletRepo:    walletRepo,
+		allowanceRepo: allowanceRepo,
+		readAllowances: func(ctx context.Context, alchemyAPIKey string, chainID int, owner string, queries []blockchain.AllowanceQuery) ([]*big.Int, error) {
+			return blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "").GetAllowances(ctx, chainID, owner, queries)
+		},
+		clock: clock.System,
+	}
+}
+
+// SetContracts adds what the explorer knows of each spender, with its security score,
+// to verifications
+func (s *AllowanceService) SetContracts(contracts *ContractService) {
+	s.contracts = contracts
+}
+
+// Verify reads the allowances the user's wallet has granted on chain and stores them.
+// It checks the wallet's stored allowances, narrowed to token and spender when set,
+// and the token and spender pair itself when both are set but nothing is stored for it.
+func (s *AllowanceService) Verify(ctx context.Context, userID uuid.UUID, walletAddress string, chainID int, token, spender, alchemyAPIKey string) (*models.AllowanceVerification, error) {
+	for _, param := range []string{token, spender} {
+		if param != "" && !address.IsEVM(param) {
+			return nil, errors.BadRequest("Invalid address " + param)
+		}
+	}
+	token, spender = address.Normalize(token), address.Normalize(spender)
+
+	wallet, err := s.userWallet(ctx, userID, walletAddress, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.allowanceRepo.GetByWallet(ctx, wallet.ID, token, spender)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	checks := make([]models.AllowanceCheck, 0, len(stored)+1)
+	for _, allowance := range stored {
+		storedAllowance, updatedAt := allowance.Allowance, allowance.UpdatedAt
+		checks = append(checks, models.AllowanceCheck{
+			TokenAddress:    allowance.Token.Address,
+			SpenderAddress:  allowance.SpenderAddress,
+			StoredAllowance: &storedAllowance,
+			StoredUpdatedAt: &updatedAt,
+			LastVerifiedAt:  allowance.VerifiedAt,
+		})
+	}
+	if token != "" && spender != "" && len(stored) == 0 {
+		checks = append(checks, models.AllowanceCheck{TokenAddress: token, SpenderAddress: spender})
+	}
+	if len(checks) > maxAllowanceChecks {
+		return nil, errors.BadRequest("Too many allowances to check at once; narrow by token or spender")
+	}
+
+	result := &models.AllowanceVerification{Wallet: wallet.Address, ChainID: chainID, VerifiedAt: s.clock.Now().UTC(), Checks: checks}
+	if len(checks) == 0 {
+		return result, nil
+	}
+
+	queries := make([]blockchain.AllowanceQuery, len(checks))
+	for i, check := range checks {
+		queries[i] = blockchain.AllowanceQuery{Token: check.TokenAddress, Spender: check.SpenderAddress}
+	}
+	live, err := s.readAllowances(ctx, alchemyAPIKey, chainID, wallet.Address, queries)
+	if err != nil {
+		logger.Warn("Failed to read allowances", "error", err, "wallet", wallet.Address, "chainID", chainID)
+		return nil, errors.ExternalServiceError("RPC", err)
+	}
+
+	for i := range result.Checks {
+		check := &result.Checks[i]
+		if live[i] == nil {
+			continue
+		}
+		allowance := live[i].String()
+		check.Allowance = &allowance
+		check.Changed = check.StoredAllowance != nil && !sameAmount(*check.StoredAllowance, allowance)
+		check.Stored, err = s.allowanceRepo.SetVerified(ctx, wallet.ID, chainID, check.TokenAddress, check.SpenderAddress, allowance, result.VerifiedAt)
+		if err != nil {
+			logger.Error("Failed to store verified allowance", "error", err, "walletID", wallet.ID, "token", check.TokenAddress)
+		}
+	}
+	s.attachSpenderContracts(ctx, result)
+	return result, nil
+}
+
+// attachSpenderContracts sets each check's spender contract when it was looked up
+func (s *AllowanceService) attachSpenderContracts(ctx context.Context, result *models.AllowanceVerification) {
+	if s.contracts == nil {
+		return
+	}
+	spenders := make([]string, len(result.Checks))
+	for i, check := range result.Checks {
+		spenders[i] = check.SpenderAddress
+	}
+	contracts, err := s.contracts.Lookup(ctx, result.ChainID, spenders)
+	if err != nil {
+		logger.Warn("Failed to get spender contracts", "error", err, "chainID", result.ChainID)
+		return
+	}
+	for i := range result.Checks {
+		result.Checks[i].SpenderContract = contracts[address.Normalize(result.Checks[i].SpenderAddress)]
+	}
+}
+
+// userWallet returns the user's EVM wallet with the address on the chain
+func (s *AllowanceService) userWallet(ctx context.Context, userID uuid.UUID, walletAddress string, chainID int) (*models.Wallet, error) {
+	if !address.IsEVM(walletAddress) {
+		return nil, errors.BadRequest("Invalid wallet address")
+	}
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	for _, wallet := range wallets {
+		if wallet.ChainID == chainID && address.Equal(wallet.Address, walletAddress) {
+			return wallet, nil
+		}
+	}
+	return nil, errors.NotFound("Wallet")
+}
+
+// sameAmount reports whether two base-10 integer amounts are equal, whatever their
+// formatting
+func sameAmount(a, b string) bool {
+	x, okX := new(big.Int).SetString(a, 10)
+	y, okY := new(big.Int).SetString(b, 10)
+	return okX && okY && x.Cmp(y) == 0
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowanceWallets returns the user's wallets
+type allowanceWallets struct {
+	repos.WalletRepository
+	wallets []*models.Wallet
+}
+
+func (w *allowanceWallets) GetByUserID(_ context.Context, userID uuid.UUID) ([]*models.Wallet, error) {
+	var owned []*models.Wallet
+	for _, wallet := range w.wallets {
+		if wallet.UserID == userID {
+			owned = append(owned, wallet)
+		}
+	}
+	return owned, nil
+}
+
+// memoryAllowances keeps allowances by token and spender; only known tokens are stored
+type memoryAllowances struct {
+	rows   map[string]*models.TokenAllowance
+	tokens map[string]bool
+}
+
+func (m *memoryAllowances) GetByWallet(_ context.Context, walletID uuid.UUID, token, spender string) ([]*models.TokenAllowance, error) {
+	var found []*models.TokenAllowance
+	for _, row := range m.rows {
+		if row.WalletID == walletID && (token == "" || row.Token.Address == token) && (spender == "" || row.SpenderAddress == spender) {
+			copied := *row
+			found = append(found, &copied)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryAllowances) SetVerified(_ context.Context, walletID uuid.UUID, _ int, token, spender, allowance string, verifiedAt time.Time) (bool, error) {
+	key := token + "/" + spender
+	row, ok := m.rows[key]
+	if !ok {
+		if allowance == "0" || !m.tokens[token] {
+			return false, nil
+		}
+		row = &models.TokenAllowance{WalletID: walletID, Token: &models.Token{Address: token}, SpenderAddress: spender}
+		m.rows[key] = row
+	}
+	row.Allowance, row.VerifiedAt = allowance, &verifiedAt
+	return true, nil
+}
+
+func TestAllowanceVerify(t *testing.T) {
+	const (
+		usdc    = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		weth    = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+		router  = "0x1111111254eeb25477b68fb85ed929f73a960582"
+		permit2 = "0x000000000022d473030f116ddee9f6b43ac78ba3"
+		unknown = "0x2222222222222222222222222222222222222222"
+	)
+	ctx := context.Background()
+	userID := uuid.New()
+	wallet := &models.Wallet{ID: uuid.New(), UserID: userID, Address: "0x8ba1f109551bd432803012645ac136ddd64dba72", ChainID: 1}
+	synced := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	allowances := &memoryAllowances{
+		tokens: map[string]bool{usdc: true, weth: true},
+		rows: map[string]*models.TokenAllowance{
+			usdc + "/" + router:  {WalletID: wallet.ID, Token: &models.Token{Address: usdc}, SpenderAddress: router, Allowance: "1000000", UpdatedAt: synced},
+			usdc + "/" + permit2: {WalletID: wallet.ID, Token: &models.Token{Address: usdc}, SpenderAddress: permit2, Allowance: "500", UpdatedAt: synced},
+		},
+	}
+	live := map[string]*big.Int{usdc + "/" + router: big.NewInt(0), usdc + "/" + permit2: big.NewInt(500), weth + "/" + router: big.NewInt(7)}
+	var reads int
+	service := NewAllowanceService(&allowanceWallets{wallets: []*models.Wallet{wallet}}, allowances)
+	service.clock = clock.NewFake(now)
+	service.readAllowances = func(_ context.Context, _ string, chainID int, owner string, queries []blockchain.AllowanceQuery) ([]*big.Int, error) {
+		reads++
+		assert.Equal(t, 1, chainID)
+		assert.Equal(t, wallet.Address, owner)
+		results := make([]*big.Int, len(queries))
+		for i, query := range queries {
+			results[i] = live[query.Token+"/"+query.Spender]
+		}
+		return results, nil
+	}
+
+	// Every stored allowance of the token is checked in one read; the revoke is caught
+	result, err := service.Verify(ctx, userID, wallet.Address, 1, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, now, result.VerifiedAt)
+	require.Len(t, result.Checks, 2)
+	assert.Equal(t, 1, reads)
+	byspender := map[string]models.AllowanceCheck{}
+	for _, check := range result.Checks {
+		byspender[check.SpenderAddress] = check
+	}
+	revoked := byspender[router]
+	assert.Equal(t, "0", *revoked.Allowance)
+	assert.Equal(t, "1000000", *revoked.StoredAllowance)
+	assert.True(t, revoked.Changed)
+	assert.True(t, revoked.Stored)
+	assert.Equal(t, synced, *revoked.StoredUpdatedAt)
+	assert.Nil(t, revoked.LastVerifiedAt)
+	assert.False(t, byspender[permit2].Changed)
+	assert.Equal(t, "0", allowances.rows[usdc+"/"+router].Allowance)
+	assert.Equal(t, now, *allowances.rows[usdc+"/"+router].VerifiedAt)
+
+	// A pair with nothing stored is read and stored if the token is known
+	result, err = service.Verify(ctx, userID, wallet.Address, 1, weth, router, "")
+	require.NoError(t, err)
+	require.Len(t, result.Checks, 1)
+	assert.Equal(t, "7", *result.Checks[0].Allowance)
+	assert.Nil(t, result.Checks[0].StoredAllowance)
+	assert.False(t, result.Checks[0].Changed)
+	assert.True(t, result.Checks[0].Stored)
+
+	// Unreadable allowances and unknown tokens aren't stored
+	result, err = service.Verify(ctx, userID, wallet.Address, 1, unknown, router, "")
+	require.NoError(t, err)
+	assert.Nil(t, result.Checks[0].Allowance)
+	assert.False(t, result.Checks[0].Stored)
+
+	// Nothing to check needs no read
+	reads = 0
+	result, err = service.Verify(ctx, userID, wallet.Address, 1, "", unknown, "")
+	require.NoError(t, err)
+	assert.Empty(t, result.Checks)
+	assert.Zero(t, reads)
+
+	_, err = service.Verify(ctx, uuid.New(), wallet.Address, 1, usdc, "", "")
+	assert.Equal(t, 404, err.(*errors.AppError).Status)
+	_, err = service.Verify(ctx, userID, wallet.Address, 137, usdc, "", "")
+	assert.Equal(t, 404, err.(*errors.AppError).Status)
+	_, err = service.Verify(ctx, userID, wallet.Address, 1, "usdc", "", "")
+	assert.Equal(t, 400, err.(*errors.AppError).Status)
+
+	service.readAllowances = func(context.Context, string, int, string, []blockchain.AllowanceQuery) ([]*big.Int, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	_, err = service.Verify(ctx, userID, wallet.Address, 1, usdc, "", "")
+	assert.Equal(t, 503, err.(*errors.AppError).Status)
+}
\ No newline at end of file
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/analytics"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// analyticsBuffer is how many events wait to be sent before new ones are dropped
+	analyticsBuffer = 10000
+	// analyticsBatch is the most events sent in one request
+	analyticsBatch = 100
+	// analyticsFlushInterval is how often waiting events are sent
+	analyticsFlushInterval = 10 * time.Second
+)
+
+// EventTracker records usage events. Services, handlers and jobs that report feature
+// adoption take one; tracking never fails or slows down what the user was doing.
+type EventTracker interface {
+	Track(userID uuid.UUID, event string, properties map[string]interface{})
+}
+
+// trackedEvent is an event waiting to be sent, still tied to its user so opt-outs can
+// be checked
+type trackedEvent struct {
+	userID uuid.UUID
+	event  analytics.Event
+}
+
+// AnalyticsService sends server-side usage events, such as wallets added and quotes
+// requested, to the analytics sink in batches. Users are only identified by
+// analytics.AnonymousID, and users who opted out are left out when a batch is sent, so
+// opting out also covers events still waiting. Events sent are also given to
+// experiments, for their results. Without a sink or experiments nothing is tracked.
+type AnalyticsService struct {
+	repo        repos.AnalyticsRepository
+	sink        analytics.Sink
+	experiments *ExperimentService
+	salt        string
+	events      chan trackedEvent
+	dropped     atomic.Int64
+	clock       clock.Clock
+}
+
+// NewAnalyticsService sends events to sink, which may be nil, identifying users by their
+// ID hashed with salt
+func NewAnalyticsService(repo repos.AnalyticsRepository, sink analytics.Sink, salt string) *AnalyticsService {
+	return &AnalyticsService{
+		repo:   repo,
+		sink:   sink,
+		salt:   salt,
+		events: make(chan trackedEvent, analyticsBuffer),
+		clock:  clock.System,
+	}
+}
+
+// SetExperiments gives experiments the events sent, so they can count exposures and
+// conversions
+func (s *AnalyticsService) SetExperiments(experiments *ExperimentService) {
+	s.experiments = experiments
+}
+
+// Track queues an event for the user
+func (s *AnalyticsService) Track(userID uuid.UUID, event string, properties map[string]interface{}) {
+	if s.sink == nil && s.experiments == nil {
+		return
+	}
+	tracked := trackedEvent{
+		userID: userID,
+		event: analytics.Event{
+			Name:        event,
+			AnonymousID: analytics.AnonymousID(s.salt, userID.String()),
+			Properties:  properties,
+			Timestamp:   s.clock.Now().UTC(),
+		},
+	}
+	select {
+	case s.events <- tracked:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Run sends queued events until ctx is done, then sends what's left
+func (s *AnalyticsService) Run(ctx context.Context) {
+	if s.sink == nil && s.experiments == nil {
+		return
+	}
+	ticker := time.NewTicker(analyticsFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]trackedEvent, 0, analyticsBatch)
+	flush := func(ctx context.Context) {
+		if dropped := s.dropped.Swap(0); dropped > 0 {
+			logger.Warn("Dropped analytics events", "count", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.send(ctx, batch); err != nil {
+			logger.Warn("Failed to send analytics events", "count", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-s.events:
+					batch = append(batch, e)
+					if len(batch) == analyticsBatch {
+						flush(context.Background())
+					}
+				default:
+					flush(context.Background())
+					return
+				}
+			}
+		case e := <-s.events:
+			batch = append(batch, e)
+			if len(batch) == analyticsBatch {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// send sends the batch's events of users who haven't opted out. When opt-outs can't be
+// read the batch is dropped rather than risk sending them.
+func (s *AnalyticsService) send(ctx context.Context, batch []trackedEvent) error {
+	userIDs := make([]uuid.UUID, 0, len(batch))
+	seen := make(map[uuid.UUID]bool, len(batch))
+	for _, e := range batch {
+		if !seen[e.userID] {
+			seen[e.userID] = true
+			userIDs = append(userIDs, e.userID)
+		}
+	}
+	optedOut, err := s.repo.OptedOut(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	sent := make([]trackedEvent, 0, len(batch))
+	events := make([]analytics.Event, 0, len(batch))
+	for _, e := range batch {
+		if !optedOut[e.userID] {
+			sent = append(sent, e)
+			events = append(events, e.event)
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if s.experiments != nil {
+		if err := s.experiments.recordEvents(ctx, sent); err != nil {
+			logger.Warn("Failed to record experiment events", "count", len(sent), "error", err)
+		}
+	}
+	if s.sink == nil {
+		return nil
+	}
+	return s.sink.Send(ctx, events)
+}
+
+// GetSettings returns whether the user opted out of analytics
+func (s *AnalyticsService) GetSettings(ctx context.Context, userID uuid.UUID) (*models.AnalyticsSettings, error) {
+	optedOutAt, err := s.repo.GetOptOut(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return &models.AnalyticsSettings{OptedOut: optedOutAt != nil, OptedOutAt: optedOutAt}, nil
+}
+
+// SetOptOut opts the user out of analytics, or back in
+func (s *AnalyticsService) SetOptOut(ctx context.Context, userID uuid.UUID, optedOut bool) (*models.AnalyticsSettings, error) {
+	if err := s.repo.SetOptOut(ctx, userID, optedOut); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return s.GetSettings(ctx, userID)
+}
\ No newline at end of file
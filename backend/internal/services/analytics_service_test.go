@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/analytics"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAnalyticsRepo struct {
+	optOuts map[uuid.UUID]time.Time
+}
+
+func (r *memoryAnalyticsRepo) GetOptOut(_ context.Context, userID uuid.UUID) (*time.Time, error) {
+	if at, ok := r.optOuts[userID]; ok {
+		return &at, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryAnalyticsRepo) SetOptOut(_ context.Context, userID uuid.UUID, optedOut bool) error {
+	if !optedOut {
+		delete(r.optOuts, userID)
+	} else if _, ok := r.optOuts[userID]; !ok {
+		r.optOuts[userID] = time.Now()
+	}
+	return nil
+}
+
+func (r *memoryAnalyticsRepo) OptedOut(_ context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	optedOut := map[uuid.UUID]bool{}
+	for _, userID := range userIDs {
+		if _, ok := r.optOuts[userID]; ok {
+			optedOut[userID] = true
+		}
+	}
+	return optedOut, nil
+}
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []analytics.Event
+}
+
+func (s *recordingSink) Send(_ context.Context, events []analytics.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func TestAnalyticsTrack(t *testing.T) {
+	repo := &memoryAnalyticsRepo{optOuts: map[uuid.UUID]time.Time{}}
+	sink := &recordingSink{}
+	service := NewAnalyticsService(repo, sink, "salt")
+	ctx := context.Background()
+
+	tracked, optedOut := uuid.New(), uuid.New()
+	_, err := service.SetOptOut(ctx, optedOut, true)
+	require.NoError(t, err)
+
+	service.Track(tracked, models.AnalyticsQuoteRequested, map[string]interface{}{"kind": "swap"})
+	service.Track(optedOut, models.AnalyticsQuoteRequested, nil)
+	service.Track(tracked, models.AnalyticsWalletAdded, nil)
+
+	// Run sends what's queued once it's stopped
+	stopped, stop := context.WithCancel(ctx)
+	stop()
+	service.Run(stopped)
+
+	require.Len(t, sink.events, 2, "opted out users' events aren't sent")
+	assert.Equal(t, models.AnalyticsQuoteRequested, sink.events[0].Name)
+	assert.Equal(t, "swap", sink.events[0].Properties["kind"])
+	assert.Equal(t, analytics.AnonymousID("salt", tracked.String()), sink.events[0].AnonymousID)
+	assert.NotContains(t, sink.events[0].AnonymousID, tracked.String())
+	assert.Equal(t, models.AnalyticsWalletAdded, sink.events[1].Name)
+}
+
+func TestAnalyticsWithoutSink(t *testing.T) {
+	service := NewAnalyticsService(&memoryAnalyticsRepo{optOuts: map[uuid.UUID]time.Time{}}, nil, "")
+	service.Track(uuid.New(), models.AnalyticsWalletAdded, nil)
+	assert.Empty(t, service.events, "nothing is tracked without a sink")
+}
+
+func TestAnalyticsSettings(t *testing.T) {
+	repo := &memoryAnalyticsRepo{optOuts: map[uuid.UUID]time.Time{}}
+	service := NewAnalyticsService(repo, nil, "")
+	ctx := context.Background()
+	userID := uuid.New()
+
+	settings, err := service.GetSettings(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, settings.OptedOut)
+
+	settings, err = service.SetOptOut(ctx, userID, true)
+	require.NoError(t, err)
+	assert.True(t, settings.OptedOut)
+	require.NotNil(t, settings.OptedOutAt)
+
+	settings, err = service.SetOptOut(ctx, userID, false)
+	require.NoError(t, err)
+	assert.False(t, settings.OptedOut)
+	assert.Nil(t, settings.OptedOutAt)
+}
\ No newline at end of file
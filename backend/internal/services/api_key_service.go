@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type APIKeyService interface {
+	SetKey(ctx context.Context, userID uuid.UUID, provider, apiKey string) (*models.UserAPIKey, error)
+	ListKeys(ctx context.Context, userID uuid.UUID) ([]models.UserAPIKey, error)
+	DeleteKey(ctx context.Context, userID uuid.UUID, provider string) error
+	ResolveKeys(ctx context.Context, userID uuid.UUID, requestKeys models.ProviderKeys) models.ProviderKeys
+}
+
+type apiKeyService struct {
+	apiKeyRepo repos.UserAPIKeyRepository
+	encryptor  *crypto.Encryptor
+}
+
+// NewAPIKeyService creates the BYO key service; a nil encryptor disables key storage
+func NewAPIKeyService(apiKeyRepo repos.UserAPIKeyRepository, encryptor *crypto.Encryptor) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		encryptor:  encryptor,
+	}
+}
+
+func (s *apiKeyService) SetKey(ctx context.Context, userID uuid.UUID, provider, apiKey string) (*models.UserAPIKey, error) {
+	if s.encryptor == nil {
+		return nil, errors.BadRequest("API key storage is not configured")
+	}
+	if !isValidAPIKeyProvider(provider) {
+		return nil, errors.BadRequest("Invalid provider. Must be one of: alchemy, coingecko, etherscan, infura")
+	}
+
+	apiKey = strings.TrimSpace(apiKey)
+	if len(apiKey) < 8 {
+		return nil, errors.BadRequest("api_key is too short")
+	}
+
+	encrypted, err := s.encryptor.Encrypt([]byte(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt api key: %w", err)
+	}
+
+	return s.apiKeyRepo.Upsert(ctx, userID, provider, encrypted, apiKey[len(apiKey)-4:])
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context, userID uuid.UUID) ([]models.UserAPIKey, error) {
+	return s.apiKeyRepo.GetByUserID(ctx, userID)
+}
+
+func (s *apiKeyService) DeleteKey(ctx context.Context, userID uuid.UUID, provider string) error {
+	if !isValidAPIKeyProvider(provider) {
+		return errors.BadRequest("Invalid provider")
+	}
+	return s.apiKeyRepo.Delete(ctx, userID, provider)
+}
+
+// ResolveKeys picks, per provider, the key sent with the request, then the user's
+// stored key. Stored keys that end up being used are metered.
+func (s *apiKeyService) ResolveKeys(ctx context.Context, userID uuid.UUID, requestKeys models.ProviderKeys) models.ProviderKeys {
+	if s.encryptor == nil {
+		return requestKeys
+	}
+
+	stored, err := s.apiKeyRepo.GetEncryptedKeys(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to load user api keys", "userID", userID, "error", err)
+		return requestKeys
+	}
+
+	resolved := requestKeys
+	slots := map[string]*string{
+		models.APIKeyProviderAlchemy:   &resolved.Alchemy,
+		models.APIKeyProviderCoinGecko: &resolved.CoinGecko,
+		models.APIKeyProviderEtherscan: &resolved.Etherscan,
+		models.APIKeyProviderInfura:    &resolved.Infura,
+	}
+
+	for provider, encrypted := range stored {
+		slot, ok := slots[provider]
+		if !ok || *slot != "" {
+			continue
+		}
+
+		plain, err := s.encryptor.Decrypt(encrypted)
+		if err != nil {
+			logger.Error("Failed to decrypt user api key", "userID", userID, "provider", provider, "error", err)
+			continue
+		}
+		*slot = string(plain)
+
+		if err := s.apiKeyRepo.IncrementUsage(ctx, userID, provider); err != nil {
+			logger.Warn("Failed to meter api key usage", "userID", userID, "provider", provider, "error", err)
+		}
+	}
+
+	return resolved
+}
+
+func isValidAPIKeyProvider(provider string) bool {
+	switch provider {
+	case models.APIKeyProviderAlchemy, models.APIKeyProviderCoinGecko, models.APIKeyProviderEtherscan, models.APIKeyProviderInfura:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/attestation"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// pinnedBalanceReader reads an address's balances on a chain; *PortfolioService in production
+type pinnedBalanceReader interface {
+	GetBalances(ctx context.Context, address string, chainID *int, hideSmall bool, pin *BalancePin, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error)
+}
+
+// AttestationService issues signed attestations of the holdings of a user's wallets,
+// for funds reporting them to third parties. Every wallet on a chain is read at the
+// same block, so the statement describes one state per chain.
+type AttestationService struct {
+	walletRepo repos.WalletRepository
+	groupRepo  repos.WalletGroupRepository
+	balances   pinnedBalanceReader
+	signer     *attestation.Signer
+	clock      clock.Clock
+}
+
+// NewAttestationService creates the service. Without a signer attestations are disabled.
+func NewAttestationService(walletRepo repos.WalletRepository, groupRepo repos.WalletGroupRepository, portfolioService *PortfolioService, signer *attestation.Signer) *AttestationService {
+	return &AttestationService{
+		walletRepo: walletRepo,
+		groupRepo:  groupRepo,
+		balances:   portfolioService,
+		signer:     signer,
+		clock:      clock.System,
+	}
+}
+
+// Enabled reports whether a signing key is configured
+func (s *AttestationService) Enabled() bool {
+	return s.signer != nil
+}
+
+// Signer returns the key attestations are signed with
+func (s *AttestationService) Signer() *attestation.Signer {
+	return s.signer
+}
+
+// Create attests the holdings of the user's mainnet EVM wallets, or of those in the
+// group if one is given. Any balance that can't be read fails the attestation, as a
+// partial one would understate the holdings.
+func (s *AttestationService) Create(ctx context.Context, userID uuid.UUID, groupID *uuid.UUID, alchemyAPIKey, coinGeckoAPIKey string) (*attestation.Attestation, error) {
+	subject := "All wallets"
+	if groupID != nil {
+		group, err := s.groupRepo.GetByID(ctx, *groupID)
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		if group == nil || group.UserID != userID {
+			return nil, errors.NotFound("Wallet group")
+		}
+		subject = group.Name
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+
+	statement := attestation.Statement{
+		IssuedAt: s.clock.Now().UTC().Truncate(time.Second),
+		Subject:  subject,
+		Wallets:  []attestation.Wallet{},
+		Blocks:   []attestation.Block{},
+		Holdings: []attestation.Holding{},
+	}
+	seen := make(map[string]bool)
+	for _, wallet := range wallets {
+		if !wallet.IsEVM() || wallet.IsTestnet {
+			continue
+		}
+		if groupID != nil && (wallet.GroupID == nil || *wallet.GroupID != *groupID) {
+			continue
+		}
+		address := strings.ToLower(wallet.Address)
+		key := fmt.Sprintf("%d:%s", wallet.ChainID, address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		statement.Wallets = append(statement.Wallets, attestation.Wallet{Address: address, ChainID: wallet.ChainID})
+	}
+	if len(statement.Wallets) == 0 {
+		return nil, errors.BadRequest("No mainnet EVM wallets to attest")
+	}
+	sort.Slice(statement.Wallets, func(i, j int) bool {
+		a, b := statement.Wallets[i], statement.Wallets[j]
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		return a.Address < b.Address
+	})
+
+	// The first read on a chain fixes its block and the others are pinned to it
+	blocks := make(map[int]*models.DataBlock)
+	totalValue := 0.0
+	for _, wallet := range statement.Wallets {
+		chainID := wallet.ChainID
+		pin := &BalancePin{}
+		if block, ok := blocks[chainID]; ok {
+			pin.Block = &block.BlockNumber
+		}
+		portfolio, err := s.balances.GetBalances(ctx, wallet.Address, &chainID, false, pin, alchemyAPIKey, coinGeckoAPIKey)
+		if err != nil {
+			if _, ok := err.(*errors.AppError); ok {
+				return nil, err
+			}
+			logger.Error("Failed to read balances for attestation", "error", err, "address", wallet.Address, "chainID", chainID)
+			return nil, errors.Internal("Failed to read wallet balances")
+		}
+		if _, ok := blocks[chainID]; !ok && portfolio.Block != nil {
+			blocks[chainID] = portfolio.Block
+			statement.Blocks = append(statement.Blocks, attestation.Block{
+				ChainID:   chainID,
+				Number:    portfolio.Block.BlockNumber,
+				Hash:      portfolio.Block.BlockHash,
+				Timestamp: portfolio.Block.BlockTimestamp.UTC(),
+			})
+		}
+
+		for _, balance := range portfolio.Balances {
+			if balance.Token == nil {
+				continue
+			}
+			holding := attestation.Holding{
+				Address:  wallet.Address,
+				ChainID:  chainID,
+				Token:    strings.ToLower(balance.Token.Address),
+				Symbol:   balance.Token.Symbol,
+				Decimals: balance.Token.Decimals,
+				Balance:  balance.Balance,
+			}
+			if balance.BalanceUSD != nil {
+				holding.ValueUSD = fmt.Sprintf("%.2f", *balance.BalanceUSD)
+				totalValue += *balance.BalanceUSD
+			}
+			statement.Holdings = append(statement.Holdings, holding)
+		}
+	}
+	sort.SliceStable(statement.Holdings, func(i, j int) bool {
+		a, b := statement.Holdings[i], statement.Holdings[j]
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		return a.Token < b.Token
+	})
+	statement.TotalValueUSD = fmt.Sprintf("%.2f", totalValue)
+
+	signed, err := s.signer.Sign(statement)
+	if err != nil {
+		logger.Error("Failed to sign attestation", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to sign attestation")
+	}
+	logger.Info("Issued portfolio attestation",
+		"userID", userID,
+		"wallets", len(statement.Wallets),
+		"holdings", len(statement.Holdings))
+	return signed, nil
+}
+
+// Verify checks an attestation against the server's key
+func (s *AttestationService) Verify(signed *attestation.Attestation) error {
+	if signed.KeyID != s.signer.KeyID() {
+		return fmt.Errorf("signed with unknown key %q", signed.KeyID)
+	}
+	return attestation.Verify(signed, s.signer.PublicKey())
+}
\ No newline at end of file
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/attestation"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attestationWalletsFake struct {
+	repos.WalletRepository
+	wallets []*models.Wallet
+}
+
+func (r attestationWalletsFake) GetByUserID(context.Context, uuid.UUID) ([]*models.Wallet, error) {
+	return r.wallets, nil
+}
+
+type attestationGroupsFake struct {
+	repos.WalletGroupRepository
+	groups map[uuid.UUID]*models.WalletGroup
+}
+
+func (r attestationGroupsFake) GetByID(_ context.Context, id uuid.UUID) (*models.WalletGroup, error) {
+	return r.groups[id], nil
+}
+
+// headBalances serves every address 1 USDC, at the head of each chain unless pinned
+type headBalances struct {
+	heads map[int]int64
+	pins  []*int64
+	err   error
+}
+
+func (b *headBalances) GetBalances(_ context.Context, address string, chainID *int, _ bool, pin *BalancePin, _, _ string) (*PortfolioBalances, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.pins = append(b.pins, pin.Block)
+	number := b.heads[*chainID]
+	if pin.Block != nil {
+		number = *pin.Block
+	}
+	// The chains move on between reads
+	b.heads[*chainID]++
+	return &PortfolioBalances{
+		Balances: []*models.Balance{{
+			Token:      &models.Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC", Decimals: 6},
+			Balance:    "1000000",
+			BalanceUSD: utils.Float64Ptr(1),
+		}},
+		Block: &models.DataBlock{
+			ChainID:        *chainID,
+			BlockNumber:    number,
+			BlockHash:      fmt.Sprintf("0x%x", number),
+			BlockTimestamp: time.Unix(number, 0),
+			Pinned:         true,
+		},
+	}, nil
+}
+
+func TestAttestationServiceCreate(t *testing.T) {
+	userID := uuid.New()
+	groupID := uuid.New()
+	wallet := func(address string, chainID int, group *uuid.UUID) *models.Wallet {
+		return &models.Wallet{ID: uuid.New(), UserID: userID, Address: address, ChainID: chainID, GroupID: group}
+	}
+	cosmos := wallet("cosmos1hsk6jryyqjfhp5dhc55tc9jtckygx0eph6dd02", 0, nil)
+	cosmos.Chain = &models.ChainRef{Namespace: models.ChainNamespaceCosmos, Reference: "cosmoshub-4"}
+	testnet := wallet("0x3333333333333333333333333333333333333333", 80002, nil)
+	testnet.IsTestnet = true
+	wallets := []*models.Wallet{
+		wallet("0x2222222222222222222222222222222222222222", 137, nil),
+		wallet("0xAAAA111111111111111111111111111111111111", 1, &groupID),
+		wallet("0x1111111111111111111111111111111111111111", 1, nil),
+		wallet("0xaaaa111111111111111111111111111111111111", 1, nil),
+		cosmos,
+		testnet,
+	}
+
+	signer, err := attestation.NewSigner(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	require.NoError(t, err)
+	balances := &headBalances{heads: map[int]int64{1: 100, 137: 500}}
+	issuedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := &AttestationService{
+		walletRepo: attestationWalletsFake{wallets: wallets},
+		groupRepo:  attestationGroupsFake{groups: map[uuid.UUID]*models.WalletGroup{groupID: {ID: groupID, UserID: userID, Name: "Fund I"}}},
+		balances:   balances,
+		signer:     signer,
+		clock:      clock.NewFake(issuedAt.Add(400 * time.Millisecond)),
+	}
+	ctx := context.Background()
+
+	signed, err := service.Create(ctx, userID, nil, "", "")
+	require.NoError(t, err)
+	require.NoError(t, service.Verify(signed))
+
+	// Mainnet EVM wallets only, once per address and chain
+	statement := signed.Statement
+	assert.Equal(t, "All wallets", statement.Subject)
+	assert.Equal(t, issuedAt, statement.IssuedAt)
+	assert.Equal(t, []attestation.Wallet{
+		{Address: "0x1111111111111111111111111111111111111111", ChainID: 1},
+		{Address: "0xaaaa111111111111111111111111111111111111", ChainID: 1},
+		{Address: "0x2222222222222222222222222222222222222222", ChainID: 137},
+	}, statement.Wallets)
+
+	// Every wallet on a chain is read at the block the first read was pinned to
+	block := int64(100)
+	assert.Equal(t, []*int64{nil, &block, nil}, balances.pins)
+	assert.Equal(t, []attestation.Block{
+		{ChainID: 1, Number: 100, Hash: "0x64", Timestamp: time.Unix(100, 0).UTC()},
+		{ChainID: 137, Number: 500, Hash: "0x1f4", Timestamp: time.Unix(500, 0).UTC()},
+	}, statement.Blocks)
+	require.Len(t, statement.Holdings, 3)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", statement.Holdings[0].Token)
+	assert.Equal(t, "1000000", statement.Holdings[0].Balance)
+	assert.Equal(t, "3.00", statement.TotalValueUSD)
+
+	// A group narrows the wallets
+	signed, err = service.Create(ctx, userID, &groupID, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Fund I", signed.Statement.Subject)
+	assert.Len(t, signed.Statement.Wallets, 1)
+
+	// Someone else's group isn't found
+	_, err = service.Create(ctx, uuid.New(), &groupID, "", "")
+	require.Error(t, err)
+	assert.Equal(t, 404, err.(*errors.AppError).Status)
+
+	// A failed read fails the whole attestation rather than understating it
+	balances.err = fmt.Errorf("rate limited")
+	_, err = service.Create(ctx, userID, nil, "", "")
+	require.Error(t, err)
+	assert.Equal(t, 500, err.(*errors.AppError).Status)
+
+	// Edits are caught
+	signed.Statement.TotalValueUSD = "1000000.00"
+	assert.ErrorIs(t, service.Verify(signed), attestation.ErrPayloadMismatch)
+}
\ No newline at end of file
@@ -4,12 +4,14 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	stderrors "errors"
 	"fmt"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/middleware"
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -19,6 +21,7 @@ type AuthService struct {
 	walletRepo repos.WalletRepository
 	jwtSecret  string
 	jwtExpiry  int // hours
+	clock      clock.Clock
 }
 
 func NewAuthService(userRepo repos.UserRepository, walletRepo repos.WalletRepository, jwtSecret string, jwtExpiry int) *AuthService {
@@ -27,6 +30,7 @@ func NewAuthService(userRepo repos.UserRepository, walletRepo repos.WalletReposi
 		walletRepo: walletRepo,
 		jwtSecret:  jwtSecret,
 		jwtExpiry:  jwtExpiry,
+		clock:      clock.System,
 	}
 }
 
@@ -42,6 +46,9 @@ func (s *AuthService) GenerateNonce(ctx context.Context, address string) (string
 	// Get or create user
 	_, err := s.userRepo.GetByAddress(ctx, address)
 	if err != nil {
+		if !stderrors.Is(err, repos.ErrNotFound) {
+			return "", errors.DatabaseError(err)
+		}
 		// Create new user if not exists
 		_, err = s.userRepo.Create(ctx, address, nonce)
 		if err != nil {
@@ -102,8 +109,8 @@ func (s *AuthService) generateJWT(user *models.User) (string, error) {
 	claims := &middleware.Claims{
 		Address: user.Address,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(s.jwtExpiry))),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(s.clock.Now().Add(time.Hour * time.Duration(s.jwtExpiry))),
+			IssuedAt:  jwt.NewNumericDate(s.clock.Now()),
 			ID:        user.ID.String(),
 		},
 	}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxBalanceRefreshWallets caps the wallets one refresh may list; leave them out to
+	// refresh every wallet
+	maxBalanceRefreshWallets = 10000
+	// balanceRefreshListLimit is how many of the latest refreshes are listed
+	balanceRefreshListLimit = 50
+)
+
+// BalanceRefreshService lets admins queue bulk balance refreshes and follow their
+// progress. The worker's balance refresh job does the refreshing.
+type BalanceRefreshService struct {
+	refreshRepo repos.BalanceRefreshRepository
+}
+
+func NewBalanceRefreshService(refreshRepo repos.BalanceRefreshRepository) *BalanceRefreshService {
+	return &BalanceRefreshService{refreshRepo: refreshRepo}
+}
+
+// Create queues a refresh of the selected wallets on behalf of the admin
+func (s *BalanceRefreshService) Create(ctx context.Context, adminID uuid.UUID, req *models.CreateBalanceRefreshRequest) (*models.BalanceRefresh, error) {
+	if len(req.WalletIDs) > maxBalanceRefreshWallets {
+		return nil, errors.BadRequest(fmt.Sprintf("At most %d wallets can be refreshed at once; leave wallet_ids out to refresh every wallet", maxBalanceRefreshWallets))
+	}
+	if req.ChainID != nil && !blockchain.IsSupportedChain(*req.ChainID) {
+		return nil, errors.BadRequest(fmt.Sprintf("Unsupported chain ID: %d", *req.ChainID))
+	}
+
+	refresh := &models.BalanceRefresh{
+		RequestedBy: &adminID,
+		WalletIDs:   req.WalletIDs,
+		ChainID:     req.ChainID,
+	}
+	if err := s.refreshRepo.Create(ctx, refresh); err != nil {
+		logger.Error("Failed to create balance refresh", "error", err)
+		return nil, errors.Internal("Failed to create balance refresh")
+	}
+
+	logger.Info("Queued balance refresh", "refreshId", refresh.ID, "adminId", adminID, "total", refresh.Total)
+	refresh.Progress = refresh.DonePercent()
+	return refresh, nil
+}
+
+// Get returns a refresh with its progress
+func (s *BalanceRefreshService) Get(ctx context.Context, id uuid.UUID) (*models.BalanceRefresh, error) {
+	refresh, err := s.refreshRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if refresh == nil {
+		return nil, errors.NotFound("Balance refresh")
+	}
+	refresh.Progress = refresh.DonePercent()
+	return refresh, nil
+}
+
+// List returns the latest refreshes, newest first
+func (s *BalanceRefreshService) List(ctx context.Context) ([]*models.BalanceRefresh, error) {
+	refreshes, err := s.refreshRepo.List(ctx, balanceRefreshListLimit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	for _, refresh := range refreshes {
+		refresh.Progress = refresh.DonePercent()
+	}
+	return refreshes, nil
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/billing"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// FeatureFlagBilling turns on checkout, subscription status and invoices when its value
+// has "enabled": true. Stripe's webhooks are handled either way, so subscriptions taken
+// out while it was on stay in step after it's turned off.
+const FeatureFlagBilling = "billing"
+
+// maxInvoices is the most invoices listed at once
+const maxInvoices = 100
+
+// CheckoutCreator opens Stripe checkout sessions and reads the subscriptions they start
+type CheckoutCreator interface {
+	CreateCheckoutSession(ctx context.Context, params billing.CheckoutParams) (*billing.CheckoutSession, error)
+	GetSubscription(ctx context.Context, id string) (*billing.Subscription, error)
+}
+
+// BillingService sells the pro tier as a Stripe subscription. Stripe's webhooks are the
+// source of truth: the subscription's status they report is kept on the user's billing
+// account and synced onto their plan tier, with admin limit overrides left alone and
+// tiers an admin set kept until the admin changes them.
+type BillingService struct {
+	billingRepo     repos.BillingRepository
+	planRepo        repos.PlanRepository
+	featureFlagRepo repos.FeatureFlagRepository
+	userRepo        repos.UserRepository
+	checkout        CheckoutCreator
+	config          billing.Config
+	clock           clock.Clock
+	// uow applies an event and records it handled together
+	uow repos.UnitOfWork
+}
+
+func NewBillingService(billingRepo repos.BillingRepository, planRepo repos.PlanRepository, featureFlagRepo repos.FeatureFlagRepository, userRepo repos.UserRepository, checkout CheckoutCreator, config billing.Config) *BillingService {
+	return &BillingService{
+		billingRepo:     billingRepo,
+		planRepo:        planRepo,
+		featureFlagRepo: featureFlagRepo,
+		userRepo:        userRepo,
+		checkout:        checkout,
+		config:          config,
+		clock:           clock.System,
+		uow:             repos.NoUnitOfWork,
+	}
+}
+
+// SetUnitOfWork makes a Stripe event's writes and its record as handled commit together,
+// so an event failing part way through is applied from scratch when Stripe retries it
+func (s *BillingService) SetUnitOfWork(uow repos.UnitOfWork) {
+	s.uow = uow
+}
+
+// Enabled reports whether an admin has turned billing on
+func (s *BillingService) Enabled(ctx context.Context) bool {
+	flag, err := s.featureFlagRepo.GetByName(ctx, FeatureFlagBilling)
+	if err != nil || flag == nil {
+		return false
+	}
+	enabled, _ := flag.Value["enabled"].(bool)
+	return enabled
+}
+
+// WebhookEnabled reports whether a webhook signing secret is configured
+func (s *BillingService) WebhookEnabled() bool {
+	return s.config.WebhookSecret != ""
+}
+
+// GetStatus returns the user's plan tier with their subscription
+func (s *BillingService) GetStatus(ctx context.Context, userID uuid.UUID) (*models.BillingStatus, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Billing is disabled")
+	}
+	plan, err := s.planRepo.GetPlan(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	account, err := s.billingRepo.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return &models.BillingStatus{Tier: plan.Tier, Subscription: account}, nil
+}
+
+// CreateCheckout opens a Stripe checkout for the user to subscribe to the pro tier
+func (s *BillingService) CreateCheckout(ctx context.Context, userID uuid.UUID) (*models.BillingCheckout, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Billing is disabled")
+	}
+	if s.checkout == nil || s.config.PriceID == "" {
+		return nil, errors.New("BILLING_NOT_CONFIGURED", "Billing is not configured", http.StatusServiceUnavailable)
+	}
+
+	account, err := s.billingRepo.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	params := billing.CheckoutParams{
+		PriceID:           s.config.PriceID,
+		ClientReferenceID: userID.String(),
+		SuccessURL:        s.config.SuccessURL,
+		CancelURL:         s.config.CancelURL,
+	}
+	if account != nil {
+		if subscriptionTier(account, s.clock.Now()) == models.PlanTierPro {
+			return nil, errors.Conflict("Already subscribed to the pro plan")
+		}
+		params.CustomerID = account.StripeCustomerID
+	} else if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user.Email != nil {
+		params.CustomerEmail = *user.Email
+	}
+
+	session, err := s.checkout.CreateCheckoutSession(ctx, params)
+	if err != nil {
+		return nil, errors.ExternalServiceError("stripe", err)
+	}
+	return &models.BillingCheckout{SessionID: session.ID, URL: session.URL}, nil
+}
+
+// ListInvoices returns the user's latest invoices
+func (s *BillingService) ListInvoices(ctx context.Context, userID uuid.UUID, limit int) ([]*models.BillingInvoice, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Billing is disabled")
+	}
+	if limit <= 0 || limit > maxInvoices {
+		limit = maxInvoices
+	}
+	invoices, err := s.billingRepo.ListInvoices(ctx, userID, limit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return invoices, nil
+}
+
+// HandleWebhook verifies and applies a Stripe event. Events already handled are
+// acknowledged without being applied again; an event that fails is left unrecorded so
+// Stripe's retry applies it.
+func (s *BillingService) HandleWebhook(ctx context.Context, body []byte, signature string) error {
+	event, err := billing.ParseEvent(body, signature, s.config.WebhookSecret, s.clock.Now())
+	if stderrors.Is(err, billing.ErrInvalidSignature) {
+		return errors.Unauthorized("Invalid webhook signature")
+	}
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+
+	handled, err := s.billingRepo.EventHandled(ctx, event.ID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if handled {
+		logger.Debug("Ignoring redelivered Stripe event", "eventId", event.ID)
+		return nil
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		switch event.Type {
+		case billing.EventCheckoutCompleted:
+			err = s.applyCheckout(ctx, event)
+		case billing.EventSubscriptionCreated, billing.EventSubscriptionUpdated, billing.EventSubscriptionDeleted:
+			err = s.applySubscription(ctx, event)
+		case billing.EventInvoiceFinalized, billing.EventInvoicePaid, billing.EventInvoicePaymentFailed,
+			billing.EventInvoiceVoided, billing.EventInvoiceUncollectible:
+			err = s.applyInvoice(ctx, event)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.billingRepo.RecordEvent(ctx, event.ID, event.Type); err != nil {
+			return errors.DatabaseError(err)
+		}
+		return nil
+	})
+}
+
+// ExpireGracePeriods moves users whose failed renewal wasn't paid in their grace period
+// back to the free tier, returning how many were
+func (s *BillingService) ExpireGracePeriods(ctx context.Context) (int, error) {
+	accounts, err := s.billingRepo.GetExpiredGrace(ctx, s.clock.Now())
+	if err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, account := range accounts {
+		account.GraceUntil = nil
+		err := s.uow.Do(ctx, func(ctx context.Context) error {
+			if err := s.billingRepo.SaveAccount(ctx, account); err != nil {
+				return err
+			}
+			return s.syncTier(ctx, account)
+		})
+		if err != nil {
+			return expired, err
+		}
+		logger.Info("Billing grace period expired", "userId", account.UserID, "status", account.Status)
+		expired++
+	}
+	return expired, nil
+}
+
+// applyCheckout ties the Stripe customer to the user whose checkout completed
+func (s *BillingService) applyCheckout(ctx context.Context, event *billing.Event) error {
+	session, err := event.Session()
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	userID, err := uuid.Parse(session.ClientReferenceID)
+	if err != nil || session.Customer == "" {
+		logger.Warn("Ignoring checkout session without a user", "sessionId", session.ID)
+		return nil
+	}
+
+	account, err := s.billingRepo.GetAccount(ctx, userID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if account == nil {
+		account = &models.BillingAccount{UserID: userID, Status: models.SubscriptionIncomplete}
+	}
+	if s.staleEvent(account, event) {
+		return nil
+	}
+	account.StripeCustomerID = session.Customer
+	if session.Subscription != "" {
+		if err := s.checkoutSubscription(ctx, account, session); err != nil {
+			return err
+		}
+	}
+	if err := s.billingRepo.SaveAccount(ctx, account); err != nil {
+		return errors.DatabaseError(err)
+	}
+	return s.syncTier(ctx, account)
+}
+
+// checkoutSubscription takes the status of the subscription a checkout started. The
+// subscription's own events are ignored while its customer isn't known, so when they
+// came first it's read from Stripe; without Stripe's API it follows the checkout's
+// payment, unless the account already tracks the subscription.
+func (s *BillingService) checkoutSubscription(ctx context.Context, account *models.BillingAccount, session *billing.Session) error {
+	previous := account.Status
+	switch {
+	case s.checkout != nil:
+		subscription, err := s.checkout.GetSubscription(ctx, session.Subscription)
+		if err != nil {
+			return errors.ExternalServiceError("stripe", err)
+		}
+		account.Status = subscription.Status
+		account.CancelAtPeriodEnd = subscription.CancelAtPeriodEnd
+		if subscription.CurrentPeriodEnd > 0 {
+			periodEnd := time.Unix(subscription.CurrentPeriodEnd, 0).UTC()
+			account.CurrentPeriodEnd = &periodEnd
+		}
+	case account.StripeSubscriptionID != nil && *account.StripeSubscriptionID == session.Subscription:
+	case session.PaymentStatus == billing.PaymentStatusPaid:
+		account.Status = models.SubscriptionActive
+	case session.PaymentStatus == billing.PaymentStatusNoPaymentRequired:
+		account.Status = models.SubscriptionTrialing
+	default:
+		account.Status = models.SubscriptionIncomplete
+	}
+	account.StripeSubscriptionID = &session.Subscription
+	s.updateGrace(account, previous)
+	return nil
+}
+
+// staleEvent reports whether an event was created before the latest one applied to the
+// account, recording its time otherwise. Stripe doesn't deliver events in order, and an
+// older event's state would overwrite a newer one's.
+func (s *BillingService) staleEvent(account *models.BillingAccount, event *billing.Event) bool {
+	if event.Created <= 0 {
+		return false
+	}
+	created := time.Unix(event.Created, 0).UTC()
+	if account.LastEventAt != nil && created.Before(*account.LastEventAt) {
+		logger.Info("Ignoring out-of-order Stripe event", "eventId", event.ID, "type", event.Type,
+			"userId", account.UserID, "created", created, "lastEventAt", account.LastEventAt)
+		return true
+	}
+	account.LastEventAt = &created
+	return false
+}
+
+// applySubscription records a subscription's status
+func (s *BillingService) applySubscription(ctx context.Context, event *billing.Event) error {
+	subscription, err := event.Subscription()
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	account, err := s.accountForCustomer(ctx, subscription.Customer)
+	if account == nil || err != nil {
+		return err
+	}
+	if s.staleEvent(account, event) {
+		return nil
+	}
+
+	previous := account.Status
+	account.StripeSubscriptionID = &subscription.ID
+	account.Status = subscription.Status
+	if event.Type == billing.EventSubscriptionDeleted {
+		account.Status = models.SubscriptionCanceled
+	}
+	account.CancelAtPeriodEnd = subscription.CancelAtPeriodEnd
+	if subscription.CurrentPeriodEnd > 0 {
+		periodEnd := time.Unix(subscription.CurrentPeriodEnd, 0).UTC()
+		account.CurrentPeriodEnd = &periodEnd
+	}
+	s.updateGrace(account, previous)
+	if err := s.billingRepo.SaveAccount(ctx, account); err != nil {
+		return errors.DatabaseError(err)
+	}
+	return s.syncTier(ctx, account)
+}
+
+// applyInvoice records an invoice; a failed payment starts the grace period and a paid
+// one ends it
+func (s *BillingService) applyInvoice(ctx context.Context, event *billing.Event) error {
+	invoice, err := event.Invoice()
+	if err != nil {
+		return errors.BadRequest(err.Error())
+	}
+	account, err := s.accountForCustomer(ctx, invoice.Customer)
+	if account == nil || err != nil {
+		return err
+	}
+
+	if err := s.billingRepo.SaveInvoice(ctx, billingInvoice(account.UserID, invoice)); err != nil {
+		return errors.DatabaseError(err)
+	}
+
+	previous := account.Status
+	switch event.Type {
+	case billing.EventInvoicePaymentFailed, billing.EventInvoicePaid:
+		if s.staleEvent(account, event) {
+			return nil
+		}
+	default:
+		return nil
+	}
+	switch event.Type {
+	case billing.EventInvoicePaymentFailed:
+		if account.Status == models.SubscriptionActive || account.Status == models.SubscriptionTrialing {
+			account.Status = models.SubscriptionPastDue
+		}
+	case billing.EventInvoicePaid:
+		if account.Status == models.SubscriptionPastDue || account.Status == models.SubscriptionUnpaid {
+			account.Status = models.SubscriptionActive
+		}
+	}
+	s.updateGrace(account, previous)
+	if err := s.billingRepo.SaveAccount(ctx, account); err != nil {
+		return errors.DatabaseError(err)
+	}
+	return s.syncTier(ctx, account)
+}
+
+// accountForCustomer returns the account of a Stripe customer, nil for a customer no
+// checkout of ours created
+func (s *BillingService) accountForCustomer(ctx context.Context, customerID string) (*models.BillingAccount, error) {
+	account, err := s.billingRepo.GetAccountByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if account == nil {
+		logger.Warn("Ignoring Stripe event for an unknown customer", "customerId", customerID)
+	}
+	return account, nil
+}
+
+// updateGrace starts the grace period when a paid-up subscription falls behind on
+// payment and clears it when it's settled or gone. A subscription still behind once its
+// grace period expired doesn't get another.
+func (s *BillingService) updateGrace(account *models.BillingAccount, previous string) {
+	switch account.Status {
+	case models.SubscriptionPastDue, models.SubscriptionUnpaid:
+		if account.GraceUntil == nil && (previous == models.SubscriptionActive || previous == models.SubscriptionTrialing) {
+			graceUntil := s.clock.Now().Add(s.config.GracePeriod)
+			account.GraceUntil = &graceUntil
+		}
+	default:
+		account.GraceUntil = nil
+	}
+}
+
+// syncTier puts the user on the tier their subscription pays for, unless an admin set
+// their plan; billing syncs clear UpdatedBy, so it's only set by an admin
+func (s *BillingService) syncTier(ctx context.Context, account *models.BillingAccount) error {
+	tier := subscriptionTier(account, s.clock.Now())
+	plan, err := s.planRepo.GetPlan(ctx, account.UserID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if plan.Tier == tier {
+		return nil
+	}
+	if plan.UpdatedBy != nil {
+		logger.Info("Keeping admin-set plan tier over billing", "userId", account.UserID,
+			"tier", plan.Tier, "billingTier", tier, "status", account.Status)
+		return nil
+	}
+	plan.Tier = tier
+	plan.UpdatedBy = nil
+	if _, err := s.planRepo.SetPlan(ctx, plan); err != nil {
+		return errors.DatabaseError(err)
+	}
+	logger.Info("Synced plan tier from billing", "userId", account.UserID, "tier", tier, "status", account.Status)
+	return nil
+}
+
+// subscriptionTier is the tier a subscription pays for: pro while it's active, or
+// behind on payment but within its grace period
+func subscriptionTier(account *models.BillingAccount, now time.Time) string {
+	switch account.Status {
+	case models.SubscriptionActive, models.SubscriptionTrialing:
+		return models.PlanTierPro
+	case models.SubscriptionPastDue, models.SubscriptionUnpaid:
+		if account.GraceUntil != nil && now.Before(*account.GraceUntil) {
+			return models.PlanTierPro
+		}
+	}
+	return models.PlanTierFree
+}
+
+func billingInvoice(userID uuid.UUID, invoice *billing.Invoice) *models.BillingInvoice {
+	result := &models.BillingInvoice{
+		ID:         invoice.ID,
+		UserID:     userID,
+		Status:     invoice.Status,
+		AmountDue:  invoice.AmountDue,
+		AmountPaid: invoice.AmountPaid,
+		Currency:   invoice.Currency,
+		CreatedAt:  time.Unix(invoice.Created, 0).UTC(),
+	}
+	if invoice.Number != "" {
+		result.Number = &invoice.Number
+	}
+	if invoice.HostedInvoiceURL != "" {
+		result.HostedInvoiceURL = &invoice.HostedInvoiceURL
+	}
+	if invoice.InvoicePDF != "" {
+		result.InvoicePDF = &invoice.InvoicePDF
+	}
+	if invoice.PeriodStart > 0 {
+		periodStart := time.Unix(invoice.PeriodStart, 0).UTC()
+		result.PeriodStart = &periodStart
+	}
+	if invoice.PeriodEnd > 0 {
+		periodEnd := time.Unix(invoice.PeriodEnd, 0).UTC()
+		result.PeriodEnd = &periodEnd
+	}
+	return result
+}
\ No newline at end of file
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/billing"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryBillingRepo struct {
+	accounts map[uuid.UUID]*models.BillingAccount
+	invoices []*models.BillingInvoice
+	events   map[string]bool
+}
+
+func newMemoryBillingRepo() *memoryBillingRepo {
+	return &memoryBillingRepo{accounts: map[uuid.UUID]*models.BillingAccount{}, events: map[string]bool{}}
+}
+
+func (r *memoryBillingRepo) GetAccount(_ context.Context, userID uuid.UUID) (*models.BillingAccount, error) {
+	if account, ok := r.accounts[userID]; ok {
+		copied := *account
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryBillingRepo) GetAccountByCustomer(_ context.Context, customerID string) (*models.BillingAccount, error) {
+	for _, account := range r.accounts {
+		if account.StripeCustomerID == customerID {
+			copied := *account
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryBillingRepo) SaveAccount(_ context.Context, account *models.BillingAccount) error {
+	copied := *account
+	r.accounts[account.UserID] = &copied
+	return nil
+}
+
+func (r *memoryBillingRepo) GetExpiredGrace(_ context.Context, now time.Time) ([]*models.BillingAccount, error) {
+	var expired []*models.BillingAccount
+	for _, account := range r.accounts {
+		if account.GraceUntil != nil && account.GraceUntil.Before(now) {
+			copied := *account
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+func (r *memoryBillingRepo) SaveInvoice(_ context.Context, invoice *models.BillingInvoice) error {
+	for i, existing := range r.invoices {
+		if existing.ID == invoice.ID {
+			r.invoices[i] = invoice
+			return nil
+		}
+	}
+	r.invoices = append(r.invoices, invoice)
+	return nil
+}
+
+func (r *memoryBillingRepo) ListInvoices(_ context.Context, userID uuid.UUID, limit int) ([]*models.BillingInvoice, error) {
+	return r.invoices, nil
+}
+
+func (r *memoryBillingRepo) EventHandled(_ context.Context, eventID string) (bool, error) {
+	return r.events[eventID], nil
+}
+
+func (r *memoryBillingRepo) RecordEvent(_ context.Context, eventID, _ string) error {
+	r.events[eventID] = true
+	return nil
+}
+
+type fakeCheckout struct {
+	params billing.CheckoutParams
+	// subscriptions are returned by GetSubscription, active if missing
+	subscriptions map[string]*billing.Subscription
+}
+
+func (f *fakeCheckout) CreateCheckoutSession(_ context.Context, params billing.CheckoutParams) (*billing.CheckoutSession, error) {
+	f.params = params
+	return &billing.CheckoutSession{ID: "cs_1", URL: "https://checkout.stripe.com/c/cs_1"}, nil
+}
+
+func (f *fakeCheckout) GetSubscription(_ context.Context, id string) (*billing.Subscription, error) {
+	if subscription, ok := f.subscriptions[id]; ok {
+		return subscription, nil
+	}
+	return &billing.Subscription{ID: id, Status: models.SubscriptionActive}, nil
+}
+
+type emailUsers struct {
+	repos.UserRepository
+	email string
+}
+
+func (u *emailUsers) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	return &models.User{ID: id, Email: &u.email}, nil
+}
+
+func TestBillingServiceSubscriptionLifecycle(t *testing.T) {
+	const secret = "whsec_test"
+	ctx := context.Background()
+	userID := uuid.New()
+	flags := &memoryFeatureFlagRepo{flags: map[string]*models.FeatureFlag{}}
+	billingRepo := newMemoryBillingRepo()
+	plans := &memoryPlans{plan: &models.UserPlan{UserID: userID, Tier: models.PlanTierFree, LimitOverrides: map[string]int{models.QuotaAlerts: 40}}}
+	checkout := &fakeCheckout{}
+	service := NewBillingService(billingRepo, plans, flags, &emailUsers{email: "user@example.org"}, checkout, billing.Config{
+		PriceID:       "price_pro",
+		WebhookSecret: secret,
+		GracePeriod:   72 * time.Hour,
+	})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	service.clock = clk
+
+	deliver := func(id, eventType, object string) error {
+		payload := []byte(`{"id":"` + id + `","type":"` + eventType + `","data":{"object":` + object + `}}`)
+		return service.HandleWebhook(ctx, payload, billing.Sign(payload, secret, clk.Now()))
+	}
+
+	_, err := service.CreateCheckout(ctx, userID)
+	require.Error(t, err, "billing is off until an admin turns it on")
+	assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+	flags.flags[FeatureFlagBilling] = &models.FeatureFlag{Name: FeatureFlagBilling, Value: map[string]interface{}{"enabled": true}}
+
+	session, err := service.CreateCheckout(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://checkout.stripe.com/c/cs_1", session.URL)
+	assert.Equal(t, userID.String(), checkout.params.ClientReferenceID)
+	assert.Equal(t, "user@example.org", checkout.params.CustomerEmail)
+
+	// Completing checkout puts the user on pro, keeping the overrides an admin set
+	require.NoError(t, deliver("evt_1", billing.EventCheckoutCompleted,
+		`{"id":"cs_1","client_reference_id":"`+userID.String()+`","customer":"cus_1","subscription":"sub_1"}`))
+	assert.Equal(t, models.PlanTierPro, plans.plan.Tier)
+	assert.Equal(t, 40, plans.plan.LimitOverrides[models.QuotaAlerts])
+	_, err = service.CreateCheckout(ctx, userID)
+	require.Error(t, err, "an active subscriber can't check out again")
+	assert.Equal(t, http.StatusConflict, err.(*errors.AppError).Status)
+
+	// A failed renewal starts the grace period, in which the user stays on pro
+	require.NoError(t, deliver("evt_2", billing.EventInvoicePaymentFailed,
+		`{"id":"in_1","customer":"cus_1","status":"open","amount_due":900,"currency":"usd","created":1777636800}`))
+	account := billingRepo.accounts[userID]
+	assert.Equal(t, models.SubscriptionPastDue, account.Status)
+	require.NotNil(t, account.GraceUntil)
+	assert.Equal(t, now.Add(72*time.Hour), *account.GraceUntil)
+	assert.Equal(t, models.PlanTierPro, plans.plan.Tier)
+	invoices, err := service.ListInvoices(ctx, userID, 10)
+	require.NoError(t, err)
+	require.Len(t, invoices, 1)
+	assert.Equal(t, int64(900), invoices[0].AmountDue)
+
+	// Paying it ends the grace period
+	require.NoError(t, deliver("evt_3", billing.EventInvoicePaid,
+		`{"id":"in_1","customer":"cus_1","status":"paid","amount_due":900,"amount_paid":900,"currency":"usd","created":1777636800}`))
+	assert.Equal(t, models.SubscriptionActive, billingRepo.accounts[userID].Status)
+	assert.Nil(t, billingRepo.accounts[userID].GraceUntil)
+	assert.Len(t, billingRepo.invoices, 1, "the invoice is updated, not added again")
+
+	// An unpaid renewal drops the user to free once the grace period is over
+	require.NoError(t, deliver("evt_4", billing.EventSubscriptionUpdated,
+		`{"id":"sub_1","customer":"cus_1","status":"past_due","current_period_end":1780315200}`))
+	assert.Equal(t, models.PlanTierPro, plans.plan.Tier)
+	expired, err := service.ExpireGracePeriods(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, expired)
+	clk.Advance(73 * time.Hour)
+	expired, err = service.ExpireGracePeriods(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, models.PlanTierFree, plans.plan.Tier)
+
+	// Still behind, a later update doesn't start another grace period
+	require.NoError(t, deliver("evt_5", billing.EventSubscriptionUpdated,
+		`{"id":"sub_1","customer":"cus_1","status":"unpaid","current_period_end":1780315200}`))
+	assert.Nil(t, billingRepo.accounts[userID].GraceUntil)
+	assert.Equal(t, models.PlanTierFree, plans.plan.Tier)
+
+	// Redelivered events aren't applied again
+	require.NoError(t, deliver("evt_3", billing.EventInvoicePaid, `{"id":"in_1","customer":"cus_1"}`))
+	assert.Equal(t, models.SubscriptionUnpaid, billingRepo.accounts[userID].Status)
+
+	// Events about customers no checkout of ours created are acknowledged and ignored
+	require.NoError(t, deliver("evt_6", billing.EventSubscriptionDeleted, `{"id":"sub_9","customer":"cus_9","status":"canceled"}`))
+
+	payload := []byte(`{"id":"evt_7","type":"customer.subscription.deleted","data":{"object":{}}}`)
+	err = service.HandleWebhook(ctx, payload, billing.Sign(payload, "whsec_other", clk.Now()))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.(*errors.AppError).Status)
+}
+func TestBillingServiceEventOrdering(t *testing.T) {
+	const secret = "whsec_test"
+	ctx := context.Background()
+	userID := uuid.New()
+	adminID := uuid.New()
+	billingRepo := newMemoryBillingRepo()
+	plans := &memoryPlans{plan: &models.UserPlan{UserID: userID, Tier: models.PlanTierFree, LimitOverrides: map[string]int{}}}
+	checkout := &fakeCheckout{subscriptions: map[string]*billing.Subscription{
+		"sub_1": {ID: "sub_1", Customer: "cus_1", Status: models.SubscriptionIncomplete},
+	}}
+	service := NewBillingService(billingRepo, plans, &memoryFeatureFlagRepo{flags: map[string]*models.FeatureFlag{}},
+		&emailUsers{email: "user@example.org"}, checkout, billing.Config{WebhookSecret: secret, GracePeriod: time.Hour})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.clock = clock.NewFake(now)
+
+	deliver := func(id, eventType string, created time.Time, object string) error {
+		payload := []byte(`{"id":"` + id + `","type":"` + eventType + `","created":` + strconv.FormatInt(created.Unix(), 10) +
+			`,"data":{"object":` + object + `}}`)
+		return service.HandleWebhook(ctx, payload, billing.Sign(payload, secret, now))
+	}
+
+	// Checkout takes the subscription's status: one whose first payment hasn't gone
+	// through doesn't make the user pro
+	require.NoError(t, deliver("evt_1", billing.EventCheckoutCompleted, now,
+		`{"id":"cs_1","client_reference_id":"`+userID.String()+`","customer":"cus_1","subscription":"sub_1"}`))
+	assert.Equal(t, models.SubscriptionIncomplete, billingRepo.accounts[userID].Status)
+	assert.Equal(t, models.PlanTierFree, plans.plan.Tier)
+
+	require.NoError(t, deliver("evt_2", billing.EventSubscriptionUpdated, now.Add(time.Minute),
+		`{"id":"sub_1","customer":"cus_1","status":"active","current_period_end":1780315200}`))
+	assert.Equal(t, models.PlanTierPro, plans.plan.Tier)
+
+	// An event created before the latest one applied doesn't overwrite it
+	require.NoError(t, deliver("evt_3", billing.EventSubscriptionCreated, now.Add(30*time.Second),
+		`{"id":"sub_1","customer":"cus_1","status":"incomplete"}`))
+	assert.Equal(t, models.SubscriptionActive, billingRepo.accounts[userID].Status)
+	assert.Equal(t, models.PlanTierPro, plans.plan.Tier)
+	require.NoError(t, deliver("evt_4", billing.EventInvoicePaymentFailed, now,
+		`{"id":"in_1","customer":"cus_1","status":"open","currency":"usd","created":1777636800}`))
+	assert.Equal(t, models.SubscriptionActive, billingRepo.accounts[userID].Status)
+	assert.True(t, billingRepo.events["evt_3"], "ignored events are still acknowledged")
+
+	// A tier an admin set is kept whatever the subscription does
+	plans.plan.Tier = models.PlanTierPro
+	plans.plan.UpdatedBy = &adminID
+	require.NoError(t, deliver("evt_5", billing.EventSubscriptionDeleted, now.Add(2*time.Minute),
+		`{"id":"sub_1","customer":"cus_1","status":"canceled"}`))
+	assert.Equal(t, models.SubscriptionCanceled, billingRepo.accounts[userID].Status)
+	assert.Equal(t, models.PlanTierPro, plans.plan.Tier)
+	assert.Equal(t, &adminID, plans.plan.UpdatedBy)
+}
+
+func TestBillingServiceCheckoutWithoutStripeAPI(t *testing.T) {
+	const secret = "whsec_test"
+	ctx := context.Background()
+	billingRepo := newMemoryBillingRepo()
+	plans := &memoryPlans{}
+	service := NewBillingService(billingRepo, plans, &memoryFeatureFlagRepo{flags: map[string]*models.FeatureFlag{}},
+		&emailUsers{email: "user@example.org"}, nil, billing.Config{WebhookSecret: secret})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.clock = clock.NewFake(now)
+
+	// Without a secret key the checkout's payment decides the status
+	for _, tt := range []struct {
+		paymentStatus string
+		want          string
+	}{
+		{"paid", models.SubscriptionActive},
+		{"no_payment_required", models.SubscriptionTrialing},
+		{"unpaid", models.SubscriptionIncomplete},
+	} {
+		userID := uuid.New()
+		payload := []byte(`{"id":"evt_` + tt.paymentStatus + `","type":"checkout.session.completed","data":{"object":` +
+			`{"id":"cs_1","client_reference_id":"` + userID.String() + `","customer":"cus_` + tt.paymentStatus +
+			`","subscription":"sub_1","payment_status":"` + tt.paymentStatus + `"}}}`)
+		require.NoError(t, service.HandleWebhook(ctx, payload, billing.Sign(payload, secret, now)))
+		assert.Equal(t, tt.want, billingRepo.accounts[userID].Status, tt.paymentStatus)
+	}
+}
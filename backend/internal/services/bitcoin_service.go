@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+)
+
+type BitcoinService struct {
+	bitcoinRepo   repos.BitcoinRepository
+	esploraClient *external.EsploraClient
+	quota         QuotaChecker
+	analytics     EventTracker
+}
+
+func NewBitcoinService(bitcoinRepo repos.BitcoinRepository, esploraClient *external.EsploraClient) *BitcoinService {
+	return &BitcoinService{
+		bitcoinRepo:   bitcoinRepo,
+		esploraClient: esploraClient,
+	}
+}
+
+// SetQuota makes adding accounts count against the wallet quota; nil lifts it
+func (s *BitcoinService) SetQuota(quota QuotaChecker) {
+	s.quota = quota
+}
+
+// SetAnalytics reports added accounts as wallet_added usage events
+func (s *BitcoinService) SetAnalytics(analytics EventTracker) {
+	s.analytics = analytics
+}
+
+// GetAccounts returns the user's tracked Bitcoin addresses and xpubs
+func (s *BitcoinService) GetAccounts(ctx context.Context, userID uuid.UUID) ([]*models.BitcoinAccount, error) {
+	accounts, err := s.bitcoinRepo.GetAccounts(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get bitcoin accounts", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch Bitcoin accounts")
+	}
+	if accounts == nil {
+		accounts = []*models.BitcoinAccount{}
+	}
+	return accounts, nil
+}
+
+// AddAccount starts tracking a Bitcoin address or extended public key. Its transaction
+// history is filled in by the next sync.
+func (s *BitcoinService) AddAccount(ctx context.Context, userID uuid.UUID, identifier string, label *string) (*models.BitcoinAccount, error) {
+	kind, err := blockchain.BitcoinAccountKind(identifier)
+	if err != nil {
+		return nil, errors.BadRequest("Invalid Bitcoin address or extended public key")
+	}
+	if s.quota != nil {
+		if err := s.quota.CheckQuota(ctx, userID, models.QuotaWallets); err != nil {
+			return nil, err
+		}
+	}
+
+	account := &models.BitcoinAccount{
+		UserID:     userID,
+		Kind:       kind,
+		Identifier: identifier,
+		Label:      label,
+	}
+	if err := s.bitcoinRepo.AddAccount(ctx, account); err != nil {
+		logger.Error("Failed to add bitcoin account", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to add Bitcoin account")
+	}
+	if s.analytics != nil {
+		s.analytics.Track(userID, models.AnalyticsWalletAdded, map[string]interface{}{"chain": "bitcoin", "kind": kind})
+	}
+
+	return account, nil
+}
+
+// RemoveAccount stops tracking an account and drops its stored transactions
+func (s *BitcoinService) RemoveAccount(ctx context.Context, userID, accountID uuid.UUID) error {
+	deleted, err := s.bitcoinRepo.DeleteAccount(ctx, userID, accountID)
+	if err != nil {
+		logger.Error("Failed to remove bitcoin account", "error", err, "userID", userID, "accountID", accountID)
+		return errors.Internal("Failed to remove Bitcoin account")
+	}
+	if !deleted {
+		return errors.NotFound("Bitcoin account")
+	}
+	return nil
+}
+
+// GetPortfolio reads the live balance of each tracked account. An account that can't be
+// read is reported with its error rather than failing the whole portfolio.
+func (s *BitcoinService) GetPortfolio(ctx context.Context, userID uuid.UUID, coinGeckoAPIKey string) (*models.BitcoinPortfolio, error) {
+	accounts, err := s.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &models.BitcoinPortfolio{Accounts: make([]*models.BitcoinAccountBalance, 0, len(accounts))}
+	for _, account := range accounts {
+		balance := &models.BitcoinAccountBalance{Account: account}
+		state, err := blockchain.ScanBitcoinAccount(ctx, s.esploraClient, account.Identifier)
+		if err != nil {
+			logger.Error("Failed to read bitcoin account", "error", err, "accountID", account.ID)
+			balance.Error = "Failed to read balance"
+		} else {
+			balance.AddressCount = len(state.Addresses)
+			balance.BalanceSats = state.BalanceSats
+			balance.BalanceBTC = blockchain.SatsToBTC(state.BalanceSats)
+			portfolio.TotalSats += state.BalanceSats
+		}
+		portfolio.Accounts = append(portfolio.Accounts, balance)
+	}
+	portfolio.TotalBTC = blockchain.SatsToBTC(portfolio.TotalSats)
+
+	if price := s.getBTCPrice(ctx, coinGeckoAPIKey); price != nil {
+		portfolio.BTCPriceUSD = price
+		for _, balance := range portfolio.Accounts {
+			value := balance.BalanceBTC * *price
+			balance.ValueUSD = &value
+		}
+		total := portfolio.TotalBTC * *price
+		portfolio.TotalValueUSD = &total
+	}
+
+	return portfolio, nil
+}
+
+// GetTransactions returns a page of the user's synced Bitcoin transactions, newest first
+func (s *BitcoinService) GetTransactions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.BitcoinTransaction, error) {
+	txs, err := s.bitcoinRepo.GetTransactions(ctx, userID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get bitcoin transactions", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch Bitcoin transactions")
+	}
+	if txs == nil {
+		txs = []*models.BitcoinTransaction{}
+	}
+	return txs, nil
+}
+
+// CalculatePnL computes realized and unrealized BTC PnL over the user's synced
+// transactions, treating receipts as buys and spends as sells at the day's price
+func (s *BitcoinService) CalculatePnL(ctx context.Context, userID uuid.UUID, from, to time.Time, method pnl.CalculationMethod, coinGeckoAPIKey string) (*models.PnLCalculation, error) {
+	txs, err := s.bitcoinRepo.GetTransactionsBetween(ctx, userID, from, to)
+	if err != nil {
+		logger.Error("Failed to get bitcoin transactions", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch Bitcoin transactions")
+	}
+
+	lots := pnl.BitcoinLots(txs)
+	if len(lots) == 0 {
+		return nil, errors.NotFound("Bitcoin transactions")
+	}
+
+	price := s.getBTCPrice(ctx, coinGeckoAPIKey)
+	if price == nil {
+		return nil, errors.Internal("Failed to fetch BTC price")
+	}
+
+	calculation, err := pnl.NewCalculator(method).CalculatePnL(lots, fmt.Sprintf("%.10f", *price))
+	if err != nil {
+		logger.Error("Failed to calculate bitcoin PnL", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to calculate PnL")
+	}
+	calculation.TokenAddress = models.BTCTokenAddress
+	calculation.TokenSymbol = "BTC"
+	asset := blockchain.BitcoinToken(nil).Asset().ID
+	calculation.Asset = &asset
+
+	return calculation, nil
+}
+
+// getBTCPrice returns the current BTC price, or nil when it can't be fetched
+func (s *BitcoinService) getBTCPrice(ctx context.Context, coinGeckoAPIKey string) *float64 {
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys("", coinGeckoAPIKey)
+	price, err := blockchainService.GetBTCPriceUSD(ctx)
+	if err != nil {
+		logger.Warn("Failed to get BTC price", "error", err)
+		return nil
+	}
+	return &price
+}
@@ -4,25 +4,42 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/clients"
 	"github.com/defi-dashboard/backend/internal/clients/bridge"
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 )
 
 type BridgeService struct {
 	lifiClient   clients.BridgeClient
 	socketClient clients.BridgeClient
-	cache        clients.Cache
+	quotes       *clients.QuoteCache
+	policies     ProviderPolicySource
+	prices       QuoteTokenPrices
+	balances     NativeBalanceReader
+	// shared lets concurrent identical requests of a user share one GetRoutes
+	shared singleflight.Group
 }
 
 func NewBridgeService(lifiConfig, socketConfig clients.ClientConfig) *BridgeService {
 	return &BridgeService{
 		lifiClient:   bridge.NewLiFiClient(lifiConfig),
 		socketClient: bridge.NewSocketClient(socketConfig),
-		cache:        clients.NewMemoryCache(),
+		quotes:       clients.NewQuoteCache(clients.DefaultQuoteStaleTTL, clients.DefaultQuoteNegativeTTL),
+	}
+}
+
+// SetLiFiAPIKey rotates the LI.FI API key in place
+func (s *BridgeService) SetLiFiAPIKey(key string) {
+	if rotator, ok := s.lifiClient.(clients.KeyRotator); ok {
+		rotator.SetAPIKey(key)
 	}
 }
 
@@ -34,6 +51,11 @@ type BridgeRouteRequest struct {
 	FromAmount  string `json:"fromAmount"`
 	UserAddress string `json:"userAddress"`
 	Slippage    float64 `json:"slippage"`
+	// MaxSlippage is the most slippage to accept when no route is found at Slippage
+	MaxSlippage float64 `json:"maxSlippage,omitempty"`
+	// WantsDestinationGas asks for a refuel leg when the user holds no gas on the
+	// destination chain
+	WantsDestinationGas bool `json:"wantsDestinationGas,omitempty"`
 }
 
 type BridgeRoute struct {
@@ -49,6 +71,12 @@ type BridgeRoute struct {
 	Fees          BridgeFees   `json:"fees"`
 	Steps         []BridgeStep `json:"steps"`
 	Provider      string       `json:"provider"`
+	// Relaxations lists what was loosened from the request to find the route, if anything
+	Relaxations []string `json:"relaxations,omitempty"`
+	// Cost is the route's fees priced the same way for every provider
+	Cost *QuoteCost `json:"cost,omitempty"`
+	// DestinationGas is set when the user would arrive without gas on the destination chain
+	DestinationGas *DestinationGas `json:"destinationGas,omitempty"`
 }
 
 type BridgeFees struct {
@@ -71,100 +99,227 @@ type BridgeStep struct {
 	GasLimit  string `json:"gasLimit"`
 }
 
+// Relaxations a fallback route was found with
+const (
+	RelaxationMultiTx  = "multi_tx"
+	RelaxationSlippage = "slippage"
+)
+
+// bridgeProvider is a bridge aggregator and how long its quotes are fresh
+type bridgeProvider struct {
+	name   string
+	client clients.BridgeClient
+	ttl    time.Duration
+	// multiTx is whether the provider returns multi-transaction routes when allowed
+	multiTx bool
+}
+
+func (s *BridgeService) providers() []bridgeProvider {
+	return []bridgeProvider{
+		{name: models.ProviderLiFi, client: s.lifiClient, ttl: 30 * time.Second},
+		{name: models.ProviderSocket, client: s.socketClient, ttl: 60 * time.Second, multiTx: true},
+	}
+}
+
+// SetProviderPolicies makes route requests follow the policies; nil lifts them
+func (s *BridgeService) SetProviderPolicies(policies ProviderPolicySource) {
+	s.policies = policies
+}
+
+// SetTokenPrices prices the fees quotes leave unpriced; without prices only the USD
+// amounts providers give are counted
+func (s *BridgeService) SetTokenPrices(prices QuoteTokenPrices) {
+	s.prices = prices
+}
+
+// limits returns a provider's policy for a transfer. A provider disabled on either
+// chain is disabled for it; other limits come from the source chain.
+func (s *BridgeService) limits(provider string, fromChain, toChain int) ProviderLimits {
+	if s.policies == nil {
+		return defaultProviderLimits
+	}
+	limits := s.policies.Limits(provider, fromChain)
+	limits.Disabled = limits.Disabled || s.policies.Limits(provider, toChain).Disabled
+	return limits
+}
+
+// routeAttempt is one pass over the providers. The first asks for single-transaction
+// routes at the requested slippage; fallbacks relax one more parameter each.
+type routeAttempt struct {
+	multiTx  bool
+	slippage float64
+}
+
+func routeAttempts(req BridgeRouteRequest) []routeAttempt {
+	attempts := []routeAttempt{
+		{slippage: req.Slippage},
+		{multiTx: true, slippage: req.Slippage},
+	}
+	if req.MaxSlippage > req.Slippage {
+		attempts = append(attempts, routeAttempt{multiTx: true, slippage: req.MaxSlippage})
+	}
+	return attempts
+}
+
+// GetRoutes asks every enabled provider for a route and returns them best first, by
+// output amount weighted by provider policy. When no provider has a route, they're
+// asked again with multi-transaction routes allowed and then with slippage raised to
+// the request's max; such routes list the relaxations they needed.
 func (s *BridgeService) GetRoutes(ctx context.Context, req BridgeRouteRequest) ([]BridgeRoute, error) {
-	// Convert request to unified format
-	quoteReq := clients.QuoteRequest{
-		FromChainID: strconv.Itoa(req.FromChain),
-		ToChainID:   strconv.Itoa(req.ToChain),
-		FromToken:   req.FromToken,
-		ToToken:     req.ToToken,
-		Amount:      req.FromAmount,
-		UserAddress: req.UserAddress,
-		Slippage:    req.Slippage,
-	}
-
-	// Generate cache keys
-	lifiCacheKey := clients.CacheKey{
-		Provider:    "lifi",
-		FromChain:   quoteReq.FromChainID,
-		ToChain:     quoteReq.ToChainID,
-		FromToken:   quoteReq.FromToken,
-		ToToken:     quoteReq.ToToken,
-		Amount:      quoteReq.Amount,
-		UserAddress: quoteReq.UserAddress,
-	}.String()
-
-	socketCacheKey := clients.CacheKey{
-		Provider:    "socket",
-		FromChain:   quoteReq.FromChainID,
-		ToChain:     quoteReq.ToChainID,
-		FromToken:   quoteReq.FromToken,
-		ToToken:     quoteReq.ToToken,
-		Amount:      quoteReq.Amount,
-		UserAddress: quoteReq.UserAddress,
-	}.String()
-
-	var routes []BridgeRoute
+	enabled := make([]bridgeProvider, 0, 2)
+	for _, provider := range s.providers() {
+		if !s.limits(provider.name, req.FromChain, req.ToChain).Disabled {
+			enabled = append(enabled, provider)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, errQuotingDisabled("Bridge")
+	}
+
+	gas := s.planDestinationGas(ctx, req)
+	for i, attempt := range routeAttempts(req) {
+		ranked := s.fetchRoutes(ctx, req, enabled, attempt, i > 0, gas)
+		if len(ranked) > 0 {
+			if i > 0 {
+				logger.Info("Found bridge routes with relaxed parameters",
+					"fromChain", req.FromChain, "toChain", req.ToChain, "attempt", i+1)
+			}
+			return sortRanked(ranked), nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, errors.BadRequest("No bridge routes found")
+}
+
+// GetUserRoutes is GetRoutes for a user's request. While the same user's identical
+// request is being routed, it waits for that answer instead of asking the providers again.
+func (s *BridgeService) GetUserRoutes(ctx context.Context, userID uuid.UUID, req BridgeRouteRequest) ([]BridgeRoute, error) {
+	return shareQuotes(ctx, &s.shared, userID, bridgeRouteKey(req), func(ctx context.Context) ([]BridgeRoute, error) {
+		return s.GetRoutes(ctx, req)
+	})
+}
+
+// fetchRoutes asks the providers for routes on one attempt. On fallback attempts,
+// providers the relaxations don't change are skipped: they'd only repeat their answer.
+// gas, when set, annotates the routes and may ask for refuel legs.
+func (s *BridgeService) fetchRoutes(ctx context.Context, req BridgeRouteRequest, providers []bridgeProvider, attempt routeAttempt, fallback bool, gas *destinationGasPlan) []rankedRoute[BridgeRoute] {
+	var ranked []rankedRoute[BridgeRoute]
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	// Fetch LiFi quote
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	for _, provider := range providers {
+		limits := s.limits(provider.name, req.FromChain, req.ToChain)
+		slippage := cappedSlippage(attempt.slippage, limits)
 
-		// Check cache first
-		if cachedQuote, found := s.cache.Get(lifiCacheKey); found {
-			mu.Lock()
-			routes = append(routes, s.convertQuoteToBridgeRoute(*cachedQuote))
-			mu.Unlock()
-			return
+		var relaxations []string
+		if attempt.multiTx && provider.multiTx {
+			relaxations = append(relaxations, RelaxationMultiTx)
+		}
+		if slippage > cappedSlippage(req.Slippage, limits) {
+			relaxations = append(relaxations, RelaxationSlippage)
+		}
+		if fallback && len(relaxations) == 0 {
+			continue
 		}
 
-		// Fetch from API
-		quote, err := s.lifiClient.GetQuote(ctx, quoteReq)
-		if err == nil {
-			// Cache the quote
-			s.cache.Set(lifiCacheKey, quote, 30*time.Second)
-			
-			mu.Lock()
-			routes = append(routes, s.convertQuoteToBridgeRoute(*quote))
-			mu.Unlock()
+		// Convert request to unified format
+		quoteReq := clients.QuoteRequest{
+			FromChainID:  strconv.Itoa(req.FromChain),
+			ToChainID:    strconv.Itoa(req.ToChain),
+			FromToken:    req.FromToken,
+			ToToken:      req.ToToken,
+			Amount:       req.FromAmount,
+			UserAddress:  req.UserAddress,
+			Slippage:     slippage,
+			AllowMultiTx: attempt.multiTx,
+		}
+		if gas != nil {
+			quoteReq.FromAmountForGas = gas.fromAmountForGas
+		}
+		cacheKey := clients.CacheKey{
+			Provider:    provider.name,
+			FromChain:   quoteReq.FromChainID,
+			ToChain:     quoteReq.ToChainID,
+			FromToken:   quoteReq.FromToken,
+			ToToken:     quoteReq.ToToken,
+			Amount:      quoteReq.Amount,
+			UserAddress: quoteReq.UserAddress,
 		}
-	}()
+		var variant []string
+		if fallback {
+			variant = append(variant, strings.Join(relaxations, "+")+"@"+strconv.FormatFloat(slippage, 'f', -1, 64))
+		}
+		if quoteReq.FromAmountForGas != "" {
+			variant = append(variant, "gas@"+quoteReq.FromAmountForGas)
+		}
+		cacheKey.Variant = strings.Join(variant, "|")
 
-	// Fetch Socket quote
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-		// Check cache first
-		if cachedQuote, found := s.cache.Get(socketCacheKey); found {
-			mu.Lock()
-			routes = append(routes, s.convertQuoteToBridgeRoute(*cachedQuote))
-			mu.Unlock()
-			return
-		}
+			// Served from cache where possible; expired quotes are refreshed by one call
+			quote, err := s.quotes.Get(ctx, cacheKey.String(), provider.ttl, func(ctx context.Context) (*clients.Quote, error) {
+				return provider.client.GetQuote(ctx, quoteReq)
+			})
+			if err != nil {
+				return
+			}
 
-		// Fetch from API
-		quote, err := s.socketClient.GetQuote(ctx, quoteReq)
-		if err == nil {
-			// Cache the quote
-			s.cache.Set(socketCacheKey, quote, 60*time.Second)
-			
+			route := s.convertQuoteToBridgeRoute(*quote)
+			route.Cost = quoteCost(ctx, s.prices, *quote, "")
+			if !withinFeeCap(feeCapTotal(route.Fees.Total, route.Cost), limits) {
+				return
+			}
+			route.Relaxations = relaxations
+			route.DestinationGas = gas.annotate(*quote)
 			mu.Lock()
-			routes = append(routes, s.convertQuoteToBridgeRoute(*quote))
+			ranked = append(ranked, rankedRoute[BridgeRoute]{route: route, score: routeScore(route.ToAmount, limits.Weight)})
 			mu.Unlock()
-		}
-	}()
-
+		}()
+	}
 	wg.Wait()
 
-	if len(routes) == 0 {
-		return nil, errors.BadRequest("No bridge routes found")
+	return ranked
+}
+
+// DestinationHash returns the transaction a bridge transfer arrived in on another chain,
+// asking LI.FI and then Socket. It returns an empty hash if neither has delivered it.
+func (s *BridgeService) DestinationHash(ctx context.Context, fromChainID, toChainID int, txHash string) (string, error) {
+	req := clients.TransferStatusRequest{
+		FromChainID: strconv.Itoa(fromChainID),
+		ToChainID:   strconv.Itoa(toChainID),
+		TxHash:      txHash,
 	}
 
-	return routes, nil
+	var lastErr error
+	failed := 0
+	providers := []clients.BridgeClient{s.lifiClient, s.socketClient}
+	for _, provider := range providers {
+		statusClient, ok := provider.(clients.BridgeStatusClient)
+		if !ok {
+			failed++
+			continue
+		}
+
+		status, err := statusClient.GetTransferStatus(ctx, req)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", provider.GetProviderName(), err)
+			failed++
+			continue
+		}
+		if status.Status == clients.TransferStatusDone && status.DestinationChainID == req.ToChainID && status.DestinationTxHash != "" {
+			return status.DestinationTxHash, nil
+		}
+	}
+
+	if failed == len(providers) && lastErr != nil {
+		return "", lastErr
+	}
+	return "", nil
 }
 
 // convertQuoteToBridgeRoute converts a unified quote to the legacy BridgeRoute format
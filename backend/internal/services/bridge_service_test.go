@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubBridgeClient quotes only requests that pass accept
+type stubBridgeClient struct {
+	clients.BridgeClient
+	name   string
+	accept func(req clients.QuoteRequest) bool
+
+	mu   sync.Mutex
+	seen []clients.QuoteRequest
+}
+
+func (c *stubBridgeClient) GetQuote(ctx context.Context, req clients.QuoteRequest) (*clients.Quote, error) {
+	c.mu.Lock()
+	c.seen = append(c.seen, req)
+	c.mu.Unlock()
+
+	if !c.accept(req) {
+		return nil, clients.ErrNoRoutes
+	}
+	quote := &clients.Quote{
+		ID:          c.name + "-route",
+		Provider:    c.name,
+		FromChainID: req.FromChainID,
+		ToChainID:   req.ToChainID,
+		FromAmount:  req.Amount,
+		ToAmount:    "990000",
+	}
+	if req.FromAmountForGas != "" {
+		quote.Refuel = &clients.Refuel{Protocol: c.name + "-gas", FromToken: clients.Token{Address: req.FromToken}, FromAmount: req.FromAmountForGas, ToAmount: "5000000000000000000"}
+	}
+	return quote, nil
+}
+
+func newStubBridgeService(lifi, socket *stubBridgeClient) *BridgeService {
+	return &BridgeService{
+		lifiClient:   lifi,
+		socketClient: socket,
+		quotes:       clients.NewQuoteCache(clients.DefaultQuoteStaleTTL, clients.DefaultQuoteNegativeTTL),
+	}
+}
+
+func TestBridgeService_GetRoutesFallback(t *testing.T) {
+	req := BridgeRouteRequest{
+		FromChain:   1,
+		ToChain:     137,
+		FromToken:   "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		ToToken:     "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359",
+		FromAmount:  "1000000",
+		UserAddress: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		Slippage:    0.5,
+		MaxSlippage: 2,
+	}
+
+	t.Run("first attempt", func(t *testing.T) {
+		lifi := &stubBridgeClient{name: "lifi", accept: func(clients.QuoteRequest) bool { return true }}
+		socket := &stubBridgeClient{name: "socket", accept: func(clients.QuoteRequest) bool { return false }}
+
+		routes, err := newStubBridgeService(lifi, socket).GetRoutes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+		assert.Empty(t, routes[0].Relaxations)
+		assert.False(t, socket.seen[0].AllowMultiTx)
+		assert.Len(t, socket.seen, 1)
+	})
+
+	t.Run("multi-transaction routes", func(t *testing.T) {
+		lifi := &stubBridgeClient{name: "lifi", accept: func(clients.QuoteRequest) bool { return false }}
+		socket := &stubBridgeClient{name: "socket", accept: func(r clients.QuoteRequest) bool { return r.AllowMultiTx }}
+
+		routes, err := newStubBridgeService(lifi, socket).GetRoutes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+		assert.Equal(t, "socket", routes[0].Provider)
+		assert.Equal(t, []string{RelaxationMultiTx}, routes[0].Relaxations)
+		// LI.FI has no multi-transaction routes, so it isn't re-asked at the same slippage
+		assert.Len(t, lifi.seen, 1)
+	})
+
+	t.Run("raised slippage", func(t *testing.T) {
+		lifi := &stubBridgeClient{name: "lifi", accept: func(r clients.QuoteRequest) bool { return r.Slippage >= 2 }}
+		socket := &stubBridgeClient{name: "socket", accept: func(clients.QuoteRequest) bool { return false }}
+
+		routes, err := newStubBridgeService(lifi, socket).GetRoutes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+		assert.Equal(t, "lifi", routes[0].Provider)
+		assert.Equal(t, []string{RelaxationSlippage}, routes[0].Relaxations)
+		assert.Len(t, socket.seen, 3)
+	})
+
+	t.Run("no slippage raise without a max", func(t *testing.T) {
+		lifi := &stubBridgeClient{name: "lifi", accept: func(r clients.QuoteRequest) bool { return r.Slippage >= 2 }}
+		socket := &stubBridgeClient{name: "socket", accept: func(clients.QuoteRequest) bool { return false }}
+
+		noMax := req
+		noMax.MaxSlippage = 0
+		_, err := newStubBridgeService(lifi, socket).GetRoutes(context.Background(), noMax)
+		assert.Error(t, err)
+		assert.Len(t, lifi.seen, 1)
+		assert.Len(t, socket.seen, 2)
+	})
+}
+
+// fakeQuoteTokens holds tokens by quoteTokenKey
+type fakeQuoteTokens map[string]*models.Token
+
+func (f fakeQuoteTokens) GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error) {
+	found := make(map[string]*models.Token)
+	for _, address := range addresses {
+		if token, ok := f[quoteTokenKey(chainID, address)]; ok {
+			found[strings.ToLower(address)] = token
+		}
+	}
+	return found, nil
+}
+
+// fakeNativeBalances holds balances by chain; reads fail without one
+type fakeNativeBalances map[int]int64
+
+func (f fakeNativeBalances) GetNativeBalance(ctx context.Context, chainID int, address string) (*big.Int, error) {
+	balance, ok := f[chainID]
+	if !ok {
+		return nil, errors.New("rpc down")
+	}
+	return big.NewInt(balance), nil
+}
+
+func TestBridgeService_DestinationGas(t *testing.T) {
+	req := BridgeRouteRequest{
+		FromChain:   1,
+		ToChain:     137,
+		FromToken:   "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		ToToken:     "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359",
+		FromAmount:  "1000000000",
+		UserAddress: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		Slippage:    0.5,
+	}
+	price := 1.0
+	usdc := fakeQuoteTokens{"1:" + strings.ToLower(req.FromToken): {Symbol: "USDC", Decimals: 6, PriceUSD: &price}}
+	service := func(balances NativeBalanceReader) (*BridgeService, *stubBridgeClient) {
+		lifi := &stubBridgeClient{name: "lifi", accept: func(clients.QuoteRequest) bool { return true }}
+		socket := &stubBridgeClient{name: "socket", accept: func(clients.QuoteRequest) bool { return false }}
+		s := newStubBridgeService(lifi, socket)
+		s.SetTokenPrices(usdc)
+		s.SetNativeBalances(balances)
+		return s, lifi
+	}
+
+	t.Run("gas held", func(t *testing.T) {
+		s, _ := service(fakeNativeBalances{137: 1})
+		routes, err := s.GetRoutes(context.Background(), req)
+		require.NoError(t, err)
+		assert.Nil(t, routes[0].DestinationGas)
+	})
+
+	t.Run("annotated without refuel", func(t *testing.T) {
+		s, lifi := service(fakeNativeBalances{137: 0})
+		routes, err := s.GetRoutes(context.Background(), req)
+		require.NoError(t, err)
+		require.NotNil(t, routes[0].DestinationGas)
+		assert.Equal(t, "MATIC", routes[0].DestinationGas.Symbol)
+		assert.Nil(t, routes[0].DestinationGas.Refuel)
+		assert.Empty(t, lifi.seen[0].FromAmountForGas)
+	})
+
+	t.Run("refuel leg", func(t *testing.T) {
+		s, lifi := service(fakeNativeBalances{137: 0})
+		wants := req
+		wants.WantsDestinationGas = true
+		routes, err := s.GetRoutes(context.Background(), wants)
+		require.NoError(t, err)
+		// $5 of USDC
+		assert.Equal(t, "5000000", lifi.seen[0].FromAmountForGas)
+		require.NotNil(t, routes[0].DestinationGas.Refuel)
+		assert.Equal(t, "lifi-gas", routes[0].DestinationGas.Refuel.Protocol)
+		assert.Equal(t, "5000000", routes[0].DestinationGas.Refuel.FromAmount)
+	})
+
+	t.Run("transfer too small to refuel", func(t *testing.T) {
+		s, lifi := service(fakeNativeBalances{137: 0})
+		small := req
+		small.WantsDestinationGas = true
+		small.FromAmount = "8000000"
+		routes, err := s.GetRoutes(context.Background(), small)
+		require.NoError(t, err)
+		assert.Empty(t, lifi.seen[0].FromAmountForGas)
+		assert.NotNil(t, routes[0].DestinationGas)
+	})
+
+	t.Run("native token bridged", func(t *testing.T) {
+		s, _ := service(fakeNativeBalances{137: 0})
+		native := req
+		native.ToToken = nativeQuoteAddress
+		routes, err := s.GetRoutes(context.Background(), native)
+		require.NoError(t, err)
+		assert.Nil(t, routes[0].DestinationGas)
+	})
+
+	t.Run("balance unknown", func(t *testing.T) {
+		s, _ := service(fakeNativeBalances{})
+		routes, err := s.GetRoutes(context.Background(), req)
+		require.NoError(t, err)
+		assert.Nil(t, routes[0].DestinationGas)
+	})
+}
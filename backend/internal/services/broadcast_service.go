@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// broadcastSampleSize caps the addresses shown previewing a broadcast's audience
+const broadcastSampleSize = 10
+
+// broadcastListLimit caps the broadcasts listed
+const broadcastListLimit = 50
+
+// broadcastEmailBatchSize caps the broadcast emails sent per run
+const broadcastEmailBatchSize = 200
+
+// BroadcastMailer emails a broadcast to one of its recipients
+type BroadcastMailer interface {
+	SendBroadcastEmail(ctx context.Context, to string, email *models.BroadcastEmail) error
+}
+
+func (LogEmailSender) SendBroadcastEmail(ctx context.Context, to string, email *models.BroadcastEmail) error {
+	logger.Info("Broadcast email", "to", to, "broadcastID", email.BroadcastID, "userID", email.UserID)
+	return nil
+}
+
+// BroadcastService sends admins' one-off messages to a segment of users, like the
+// holders of a token or the users with positions in an exploited protocol, as a banner
+// only they see and an email sent by the worker
+type BroadcastService struct {
+	broadcastRepo repos.BroadcastRepository
+	bannerRepo    repos.SystemBannerRepository
+	protocolRepo  repos.ProtocolRepository
+	feedRepo      repos.FeedRepository
+	mailer        BroadcastMailer
+	clock         clock.Clock
+}
+
+func NewBroadcastService(broadcastRepo repos.BroadcastRepository, bannerRepo repos.SystemBannerRepository, protocolRepo repos.ProtocolRepository, feedRepo repos.FeedRepository, mailer BroadcastMailer) *BroadcastService {
+	if mailer == nil {
+		mailer = LogEmailSender{}
+	}
+	return &BroadcastService{
+		broadcastRepo: broadcastRepo,
+		bannerRepo:    bannerRepo,
+		protocolRepo:  protocolRepo,
+		feedRepo:      feedRepo,
+		mailer:        mailer,
+		clock:         clock.System,
+	}
+}
+
+// Send previews who the broadcast reaches and, unless it's a dry run, sends it to them
+func (s *BroadcastService) Send(ctx context.Context, adminID uuid.UUID, req *models.CreateBroadcastRequest, dryRun bool) (*models.BroadcastResult, error) {
+	if strings.TrimSpace(req.Message) == "" {
+		return nil, errors.BadRequest("message is required")
+	}
+	if req.Level == "" {
+		req.Level = models.BannerLevelInfo
+	}
+	if req.Level != models.BannerLevelInfo &&
+		req.Level != models.BannerLevelWarning &&
+		req.Level != models.BannerLevelError &&
+		req.Level != models.BannerLevelSuccess {
+		return nil, errors.BadRequest("Invalid banner level. Must be one of: info, warning, error, success")
+	}
+	if !req.Banner && !req.Email {
+		return nil, errors.BadRequest("A broadcast must be sent as a banner, an email or both")
+	}
+
+	segment, err := s.resolveAudience(ctx, &req.Audience)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.broadcastRepo.PreviewAudience(ctx, segment, broadcastSampleSize)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	result := &models.BroadcastResult{Preview: preview}
+	if dryRun {
+		return result, nil
+	}
+
+	broadcast := &models.Broadcast{
+		Title:     req.Title,
+		Message:   req.Message,
+		Level:     req.Level,
+		Audience:  req.Audience,
+		Banner:    req.Banner,
+		Email:     req.Email,
+		CreatedBy: &adminID,
+	}
+	if err := s.broadcastRepo.Create(ctx, broadcast, segment); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	logger.Info("Broadcast sent", "broadcastID", broadcast.ID, "adminID", adminID,
+		"recipients", broadcast.Recipients, "emailsPending", broadcast.EmailsPending)
+
+	result.Broadcast = broadcast
+	return result, nil
+}
+
+// resolveAudience turns the audience into the segment of users the repository matches.
+// A feed item stands for the protocol and tokens its source is about.
+func (s *BroadcastService) resolveAudience(ctx context.Context, audience *models.BroadcastAudience) (repos.BroadcastSegment, error) {
+	var segment repos.BroadcastSegment
+
+	if (audience.ChainID == nil) != (audience.TokenAddress == nil || *audience.TokenAddress == "") {
+		return segment, errors.BadRequest("chain_id and token_address must be given together")
+	}
+	if audience.ChainID != nil {
+		segment.ChainID = audience.ChainID
+		segment.TokenAddress = strings.ToLower(*audience.TokenAddress)
+	}
+
+	protocol := audience.Protocol
+	if audience.FeedItemID != nil {
+		item, err := s.feedRepo.GetItem(ctx, *audience.FeedItemID)
+		if err != nil {
+			return segment, errors.DatabaseError(err)
+		}
+		if item == nil {
+			return segment, errors.NotFound("Feed item")
+		}
+		if item.Protocol == nil && len(item.Tokens) == 0 {
+			return segment, errors.BadRequest("The feed item's source isn't about a protocol or token")
+		}
+		if protocol == nil {
+			protocol = item.Protocol
+		} else if item.Protocol != nil && *item.Protocol != *protocol {
+			return segment, errors.BadRequest("The feed item is about another protocol")
+		}
+		for _, symbol := range item.Tokens {
+			segment.TokenSymbols = append(segment.TokenSymbols, strings.ToUpper(symbol))
+		}
+	}
+
+	if protocol != nil {
+		p, err := s.protocolRepo.GetBySlug(ctx, *protocol)
+		if stderrors.Is(err, repos.ErrNotFound) {
+			return segment, errors.BadRequest("Unknown protocol: " + *protocol)
+		}
+		if err != nil {
+			return segment, errors.DatabaseError(err)
+		}
+		segment.ProtocolID = &p.ID
+	}
+
+	if segment.ChainID == nil && segment.ProtocolID == nil && len(segment.TokenSymbols) == 0 {
+		return segment, errors.BadRequest("audience must name a token, a protocol or a feed item")
+	}
+	return segment, nil
+}
+
+// List returns the latest broadcasts with how many of their emails are still to be sent
+func (s *BroadcastService) List(ctx context.Context) ([]*models.Broadcast, error) {
+	broadcasts, err := s.broadcastRepo.List(ctx, broadcastListLimit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return broadcasts, nil
+}
+
+// GetBanners returns the active banners the user sees: those for everyone and those of
+// the broadcasts they're a recipient of
+func (s *BroadcastService) GetBanners(ctx context.Context, userID uuid.UUID) ([]models.SystemBanner, error) {
+	banners, err := s.bannerRepo.GetActiveForUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return banners, nil
+}
+
+// SendPendingEmails emails broadcasts to the recipients not emailed yet, returning how
+// many were sent. A failed email is retried next run.
+func (s *BroadcastService) SendPendingEmails(ctx context.Context) (int, error) {
+	emails, err := s.broadcastRepo.GetPendingEmails(ctx, broadcastEmailBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, email := range emails {
+		if err := s.mailer.SendBroadcastEmail(ctx, email.Email, email); err != nil {
+			logger.Warn("Failed to email broadcast", "broadcastID", email.BroadcastID, "userID", email.UserID, "error", err)
+			continue
+		}
+		if err := s.broadcastRepo.MarkEmailed(ctx, email.BroadcastID, email.UserID, s.clock.Now()); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
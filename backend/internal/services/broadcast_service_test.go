@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// broadcastStore records the segments previewed and the broadcasts created
+type broadcastStore struct {
+	repos.BroadcastRepository
+	previewed []repos.BroadcastSegment
+	created   []*models.Broadcast
+	pending   []*models.BroadcastEmail
+	emailed   map[uuid.UUID]bool
+}
+
+func (r *broadcastStore) PreviewAudience(_ context.Context, segment repos.BroadcastSegment, _ int) (*models.BroadcastPreview, error) {
+	r.previewed = append(r.previewed, segment)
+	return &models.BroadcastPreview{Users: 2, Emailable: 1, Sample: []string{"0xaaa", "0xbbb"}}, nil
+}
+
+func (r *broadcastStore) Create(_ context.Context, broadcast *models.Broadcast, _ repos.BroadcastSegment) error {
+	broadcast.ID = uuid.New()
+	broadcast.Recipients = 2
+	r.created = append(r.created, broadcast)
+	return nil
+}
+
+func (r *broadcastStore) GetPendingEmails(_ context.Context, _ int) ([]*models.BroadcastEmail, error) {
+	var pending []*models.BroadcastEmail
+	for _, e := range r.pending {
+		if !r.emailed[e.UserID] {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (r *broadcastStore) MarkEmailed(_ context.Context, _, userID uuid.UUID, _ time.Time) error {
+	r.emailed[userID] = true
+	return nil
+}
+
+// slugProtocols finds protocols by slug
+type slugProtocols struct {
+	repos.ProtocolRepository
+	protocols []*models.Protocol
+}
+
+func (r *slugProtocols) GetBySlug(_ context.Context, slug string) (*models.Protocol, error) {
+	for _, p := range r.protocols {
+		if p.Slug == slug {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("protocol %w", repos.ErrNotFound)
+}
+
+// feedItems finds feed items by ID
+type feedItems struct {
+	repos.FeedRepository
+	items []*models.FeedItem
+}
+
+func (r *feedItems) GetItem(_ context.Context, id uuid.UUID) (*models.FeedItem, error) {
+	for _, item := range r.items {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+// broadcastMailer records the addresses emailed, failing those to failing
+type broadcastMailer struct {
+	sent    []string
+	failing string
+}
+
+func (m *broadcastMailer) SendBroadcastEmail(_ context.Context, to string, _ *models.BroadcastEmail) error {
+	if to == m.failing {
+		return fmt.Errorf("smtp unavailable")
+	}
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+func newTestBroadcastService(protocols []*models.Protocol, items []*models.FeedItem) (*BroadcastService, *broadcastStore, *broadcastMailer) {
+	store := &broadcastStore{emailed: map[uuid.UUID]bool{}}
+	mailer := &broadcastMailer{}
+	service := NewBroadcastService(store, nil, &slugProtocols{protocols: protocols}, &feedItems{items: items}, mailer)
+	return service, store, mailer
+}
+
+func TestBroadcastServiceValidatesRequest(t *testing.T) {
+	service, store, _ := newTestBroadcastService(nil, nil)
+	chainID, address, unknown := 1, "0xA0b8", "nope"
+	missingItem := uuid.New()
+
+	tests := []struct {
+		name string
+		req  models.CreateBroadcastRequest
+	}{
+		{"no message", models.CreateBroadcastRequest{Banner: true, Audience: models.BroadcastAudience{ChainID: &chainID, TokenAddress: &address}}},
+		{"no channel", models.CreateBroadcastRequest{Message: "hi", Audience: models.BroadcastAudience{ChainID: &chainID, TokenAddress: &address}}},
+		{"bad level", models.CreateBroadcastRequest{Message: "hi", Level: "urgent", Banner: true, Audience: models.BroadcastAudience{ChainID: &chainID, TokenAddress: &address}}},
+		{"no audience", models.CreateBroadcastRequest{Message: "hi", Banner: true}},
+		{"address without chain", models.CreateBroadcastRequest{Message: "hi", Banner: true, Audience: models.BroadcastAudience{TokenAddress: &address}}},
+		{"unknown protocol", models.CreateBroadcastRequest{Message: "hi", Banner: true, Audience: models.BroadcastAudience{Protocol: &unknown}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Send(context.Background(), uuid.New(), &tt.req, false)
+			require.Error(t, err)
+			assert.Equal(t, 400, err.(*errors.AppError).Status)
+		})
+	}
+	assert.Empty(t, store.previewed)
+	assert.Empty(t, store.created)
+
+	_, err := service.Send(context.Background(), uuid.New(), &models.CreateBroadcastRequest{
+		Message: "hi", Banner: true, Audience: models.BroadcastAudience{FeedItemID: &missingItem},
+	}, false)
+	require.Error(t, err)
+	assert.Equal(t, 404, err.(*errors.AppError).Status, "a missing feed item is not found")
+}
+
+func TestBroadcastServiceTargetsFeedItemHolders(t *testing.T) {
+	slug, other := "acme", "other"
+	acme := &models.Protocol{ID: uuid.New(), Slug: "acme"}
+	item := &models.FeedItem{ID: uuid.New(), Title: "Acme exploited", Protocol: &slug, Tokens: []string{"acme", "USDC"}}
+	service, store, _ := newTestBroadcastService([]*models.Protocol{acme}, []*models.FeedItem{item})
+	adminID := uuid.New()
+	chainID, address := 1, "0xA0B8"
+
+	req := &models.CreateBroadcastRequest{
+		Message:  "Withdraw from Acme",
+		Banner:   true,
+		Email:    true,
+		Audience: models.BroadcastAudience{FeedItemID: &item.ID, ChainID: &chainID, TokenAddress: &address},
+	}
+	result, err := service.Send(context.Background(), adminID, req, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Preview.Users)
+	assert.Nil(t, result.Broadcast, "a dry run sends nothing")
+	assert.Empty(t, store.created)
+
+	require.Len(t, store.previewed, 1)
+	segment := store.previewed[0]
+	assert.Equal(t, &acme.ID, segment.ProtocolID)
+	assert.Equal(t, []string{"ACME", "USDC"}, segment.TokenSymbols)
+	assert.Equal(t, "0xa0b8", segment.TokenAddress)
+
+	result, err = service.Send(context.Background(), adminID, req, false)
+	require.NoError(t, err)
+	require.NotNil(t, result.Broadcast)
+	assert.Equal(t, models.BannerLevelInfo, result.Broadcast.Level)
+	assert.Equal(t, &adminID, result.Broadcast.CreatedBy)
+	assert.Len(t, store.created, 1)
+
+	req.Audience = models.BroadcastAudience{FeedItemID: &item.ID, Protocol: &other}
+	_, err = service.Send(context.Background(), adminID, req, true)
+	require.Error(t, err)
+	assert.Equal(t, 400, err.(*errors.AppError).Status, "the feed item and protocol must agree")
+}
+
+func TestBroadcastServiceSendsPendingEmails(t *testing.T) {
+	service, store, mailer := newTestBroadcastService(nil, nil)
+	mailer.failing = "bounce@example.com"
+	broadcastID := uuid.New()
+	store.pending = []*models.BroadcastEmail{
+		{BroadcastID: broadcastID, UserID: uuid.New(), Email: "holder@example.com", Message: "hi"},
+		{BroadcastID: broadcastID, UserID: uuid.New(), Email: "bounce@example.com", Message: "hi"},
+	}
+
+	sent, err := service.SendPendingEmails(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"holder@example.com"}, mailer.sent)
+
+	// The failed email is tried again next run; the sent one isn't
+	mailer.failing = ""
+	sent, err = service.SendPendingEmails(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"holder@example.com", "bounce@example.com"}, mailer.sent)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+)
+
+// budgetMetrics are the kinds of spending budgets can limit
+var budgetMetrics = map[string]bool{
+	models.BudgetMetricGasSpend:      true,
+	models.BudgetMetricTradingVolume: true,
+	models.BudgetMetricRealizedLoss:  true,
+}
+
+// IsBudgetMetric reports whether budgets can limit spending of the kind
+func IsBudgetMetric(metric string) bool {
+	return budgetMetrics[metric]
+}
+
+// BudgetService manages users' soft monthly budgets and works out spending against them
+// for the current calendar month from the analytics tables
+type BudgetService struct {
+	budgetRepo      repos.BudgetRepository
+	walletRepo      repos.WalletRepository
+	transactionRepo repos.TransactionRepository
+	pnlService      pnl.Service
+	clock           clock.Clock
+}
+
+func NewBudgetService(budgetRepo repos.BudgetRepository, walletRepo repos.WalletRepository, transactionRepo repos.TransactionRepository, pnlService pnl.Service) *BudgetService {
+	return &BudgetService{
+		budgetRepo:      budgetRepo,
+		walletRepo:      walletRepo,
+		transactionRepo: transactionRepo,
+		pnlService:      pnlService,
+		clock:           clock.System,
+	}
+}
+
+// GetBudgets returns the user's budgets with their progress this month
+func (s *BudgetService) GetBudgets(ctx context.Context, userID uuid.UUID) ([]*models.BudgetProgress, error) {
+	budgets, err := s.budgetRepo.GetByUser(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get budgets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch budgets")
+	}
+
+	progress := make([]*models.BudgetProgress, 0, len(budgets))
+	for _, budget := range budgets {
+		p, err := s.progress(ctx, budget)
+		if err != nil {
+			logger.Error("Failed to work out budget progress", "error", err, "userID", userID, "metric", budget.Metric)
+			return nil, errors.Internal("Failed to work out budget progress")
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// GetProgress returns the progress this month of the user's budget for a metric, or nil
+// when the user has none
+func (s *BudgetService) GetProgress(ctx context.Context, userID uuid.UUID, metric string) (*models.BudgetProgress, error) {
+	budget, err := s.budgetRepo.Get(ctx, userID, metric)
+	if err != nil || budget == nil {
+		return nil, err
+	}
+	return s.progress(ctx, budget)
+}
+
+// SetBudget creates or updates the limit of the user's budget for a metric
+func (s *BudgetService) SetBudget(ctx context.Context, userID uuid.UUID, metric string, req *models.SetBudgetRequest) (*models.Budget, error) {
+	if !IsBudgetMetric(metric) {
+		return nil, errors.BadRequest("Metric must be gas_spend, trading_volume or realized_loss")
+	}
+	if req.LimitUSD <= 0 {
+		return nil, errors.BadRequest("limit_usd must be greater than 0")
+	}
+
+	budget := &models.Budget{UserID: userID, Metric: metric, LimitUSD: req.LimitUSD}
+	if err := s.budgetRepo.Upsert(ctx, budget); err != nil {
+		logger.Error("Failed to save budget", "error", err, "userID", userID, "metric", metric)
+		return nil, errors.Internal("Failed to save budget")
+	}
+	return budget, nil
+}
+
+// DeleteBudget removes the user's budget for a metric
+func (s *BudgetService) DeleteBudget(ctx context.Context, userID uuid.UUID, metric string) error {
+	deleted, err := s.budgetRepo.Delete(ctx, userID, metric)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Budget")
+	}
+	return nil
+}
+
+// budgetPeriod returns the calendar month, in UTC, containing t
+func budgetPeriod(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func (s *BudgetService) progress(ctx context.Context, budget *models.Budget) (*models.BudgetProgress, error) {
+	from, to := budgetPeriod(s.clock.Now())
+	spent, err := s.spent(ctx, budget.UserID, budget.Metric, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &models.BudgetProgress{
+		Budget:      budget,
+		PeriodStart: from,
+		PeriodEnd:   to,
+		SpentUSD:    spent,
+		Exceeded:    spent > budget.LimitUSD,
+	}
+	if budget.LimitUSD > 0 {
+		p.Percent = spent / budget.LimitUSD * 100
+	}
+	return p, nil
+}
+
+// spent works out the user's spending of a kind in [from, to)
+func (s *BudgetService) spent(ctx context.Context, userID uuid.UUID, metric string, from, to time.Time) (float64, error) {
+	switch metric {
+	case models.BudgetMetricGasSpend:
+		fees, err := s.transactionRepo.GetFeeSpend(ctx, userID, from, to)
+		if err != nil {
+			return 0, err
+		}
+		total := 0.0
+		for _, fee := range fees {
+			if !blockchain.TestnetMode() && blockchain.IsTestnet(fee.ChainID) {
+				continue
+			}
+			total += fee.FeeUSD.Float64()
+		}
+		return total, nil
+	case models.BudgetMetricTradingVolume:
+		return s.budgetRepo.GetTradingVolume(ctx, userID, from, to)
+	case models.BudgetMetricRealizedLoss:
+		return s.realizedLoss(ctx, userID, from, to)
+	default:
+		return 0, fmt.Errorf("unknown budget metric: %s", metric)
+	}
+}
+
+// realizedLoss nets the realized PnL of the user's EVM wallets in [from, to), returning
+// the loss or zero when they're in profit. Wallets without lots are skipped.
+func (s *BudgetService) realizedLoss(ctx context.Context, userID uuid.UUID, from, to time.Time) (float64, error) {
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	if !blockchain.TestnetMode() {
+		wallets = mainnetWallets(wallets)
+	}
+
+	realized := 0.0
+	for _, wallet := range uniqueAddressWallets(wallets) {
+		if !wallet.IsEVM() {
+			continue
+		}
+		calculation, err := s.pnlService.CalculatePnL(ctx, wallet.Address, from, to, pnl.FIFO, nil)
+		if err != nil {
+			logger.Debug("No PnL for budget", "error", err, "address", wallet.Address)
+			continue
+		}
+		value, _ := strconv.ParseFloat(calculation.RealizedPnLUSD, 64)
+		realized += value
+		if calculation.NFT != nil {
+			realized += calculation.NFT.RealizedPnLUSD
+		}
+	}
+	if realized >= 0 {
+		return 0, nil
+	}
+	return -realized, nil
+}
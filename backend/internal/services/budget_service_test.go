@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type budgetRepoFake struct {
+	repos.BudgetRepository
+	budgets []*models.Budget
+	volume  float64
+}
+
+func (r *budgetRepoFake) GetByUser(context.Context, uuid.UUID) ([]*models.Budget, error) {
+	return r.budgets, nil
+}
+
+func (r *budgetRepoFake) GetTradingVolume(context.Context, uuid.UUID, time.Time, time.Time) (float64, error) {
+	return r.volume, nil
+}
+
+type budgetWalletsFake struct {
+	repos.WalletRepository
+	wallets []*models.Wallet
+}
+
+func (r budgetWalletsFake) GetByUserID(context.Context, uuid.UUID) ([]*models.Wallet, error) {
+	return r.wallets, nil
+}
+
+type budgetFeesFake struct {
+	repos.TransactionRepository
+	fees []models.StatementFeeSpend
+	from time.Time
+}
+
+func (r *budgetFeesFake) GetFeeSpend(_ context.Context, _ uuid.UUID, from, _ time.Time) ([]models.StatementFeeSpend, error) {
+	r.from = from
+	return r.fees, nil
+}
+
+// budgetPnLFake realizes the PnL given per address
+type budgetPnLFake struct {
+	pnl.Service
+	realized map[string]string
+}
+
+func (p budgetPnLFake) CalculatePnL(_ context.Context, address string, _, _ time.Time, _ pnl.CalculationMethod, _ *pnl.WashSaleRule) (*models.PnLCalculation, error) {
+	return &models.PnLCalculation{RealizedPnLUSD: p.realized[address]}, nil
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	from, to := budgetPeriod(time.Date(2024, 12, 31, 23, 0, 0, 0, time.FixedZone("UTC-5", -5*3600)))
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), to)
+}
+
+func TestBudgetServiceGetBudgets(t *testing.T) {
+	userID := uuid.New()
+	fees := &budgetFeesFake{fees: []models.StatementFeeSpend{
+		{ChainID: 1, FeeUSD: decimal.NewFromFloat(180)},
+		{ChainID: 42161, FeeUSD: decimal.NewFromFloat(20)},
+	}}
+	s := &BudgetService{
+		budgetRepo: &budgetRepoFake{budgets: []*models.Budget{
+			{UserID: userID, Metric: models.BudgetMetricGasSpend, LimitUSD: 150},
+			{UserID: userID, Metric: models.BudgetMetricRealizedLoss, LimitUSD: 1000},
+			{UserID: userID, Metric: models.BudgetMetricTradingVolume, LimitUSD: 10000},
+		}, volume: 2500},
+		walletRepo: budgetWalletsFake{wallets: []*models.Wallet{
+			{Address: "0xaaa"},
+			{Address: "0xbbb"},
+		}},
+		transactionRepo: fees,
+		pnlService:      budgetPnLFake{realized: map[string]string{"0xaaa": "-500", "0xbbb": "100"}},
+		clock:           clock.NewFake(time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)),
+	}
+
+	progress, err := s.GetBudgets(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, progress, 3)
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), fees.from)
+
+	gas := progress[0]
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), gas.PeriodEnd)
+	assert.InDelta(t, 200, gas.SpentUSD, 1e-9)
+	assert.InDelta(t, 133.33, gas.Percent, 0.01)
+	assert.True(t, gas.Exceeded)
+
+	loss := progress[1]
+	assert.InDelta(t, 400, loss.SpentUSD, 1e-9)
+	assert.InDelta(t, 40, loss.Percent, 1e-9)
+	assert.False(t, loss.Exceeded)
+
+	volume := progress[2]
+	assert.InDelta(t, 2500, volume.SpentUSD, 1e-9)
+	assert.InDelta(t, 25, volume.Percent, 1e-9)
+}
+
+func TestBudgetServiceRealizedLossIgnoresProfit(t *testing.T) {
+	s := &BudgetService{
+		walletRepo: budgetWalletsFake{wallets: []*models.Wallet{{Address: "0xaaa"}}},
+		pnlService: budgetPnLFake{realized: map[string]string{"0xaaa": "250"}},
+	}
+	loss, err := s.realizedLoss(context.Background(), uuid.New(), time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Zero(t, loss)
+}
+
+func TestBudgetServiceSetBudgetValidation(t *testing.T) {
+	s := &BudgetService{budgetRepo: &budgetRepoFake{}}
+
+	_, err := s.SetBudget(context.Background(), uuid.New(), "coffee", &models.SetBudgetRequest{LimitUSD: 10})
+	assert.Error(t, err)
+	_, err = s.SetBudget(context.Background(), uuid.New(), models.BudgetMetricGasSpend, &models.SetBudgetRequest{LimitUSD: 0})
+	assert.Error(t, err)
+}
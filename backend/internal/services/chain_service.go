@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// FeatureFlagUserCustomChains lets users, not only admins, register custom chains when
+// its value has "enabled": true
+const FeatureFlagUserCustomChains = "user_custom_chains"
+
+// chainVerifyTimeout bounds the eth_chainId check against a new chain's RPC endpoint
+const chainVerifyTimeout = 10 * time.Second
+
+// ChainService manages the custom EVM chains registered by configuration and keeps the
+// blockchain package's registry in step with them
+type ChainService struct {
+	chainRepo       repos.CustomChainRepository
+	featureFlagRepo repos.FeatureFlagRepository
+	// fetchChainID is replaced in tests
+	fetchChainID func(ctx context.Context, rpcURL string) (int64, error)
+}
+
+func NewChainService(chainRepo repos.CustomChainRepository, featureFlagRepo repos.FeatureFlagRepository) *ChainService {
+	return &ChainService{
+		chainRepo:       chainRepo,
+		featureFlagRepo: featureFlagRepo,
+		fetchChainID:    blockchain.FetchChainID,
+	}
+}
+
+// Load reads the custom chains into the blockchain registry. Processes call it at
+// startup, and the worker again periodically to pick up chains added through the API.
+func (s *ChainService) Load(ctx context.Context) error {
+	chains, err := s.chainRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	blockchain.SetCustomChains(chains)
+	return nil
+}
+
+// ListChains returns the registered custom chains
+func (s *ChainService) ListChains(ctx context.Context) ([]*models.CustomChain, error) {
+	chains, err := s.chainRepo.GetAll(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return chains, nil
+}
+
+// UsersCanRegister reports whether users may register chains themselves
+func (s *ChainService) UsersCanRegister(ctx context.Context) bool {
+	flag, err := s.featureFlagRepo.GetByName(ctx, FeatureFlagUserCustomChains)
+	if err != nil || flag == nil {
+		return false
+	}
+	enabled, _ := flag.Value["enabled"].(bool)
+	return enabled
+}
+
+// RegisterChain adds a custom chain once its RPC endpoint confirms the chain ID.
+// createdBy is set when a user rather than an admin registers the chain.
+func (s *ChainService) RegisterChain(ctx context.Context, createdBy *uuid.UUID, req *models.RegisterCustomChainRequest) (*models.CustomChain, error) {
+	chain, appErr := validateCustomChain(req)
+	if appErr != nil {
+		return nil, appErr
+	}
+	chain.CreatedBy = createdBy
+
+	existing, err := s.chainRepo.GetByChainID(ctx, chain.ChainID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if existing != nil {
+		return nil, errors.Conflict(fmt.Sprintf("chain %d is already registered", chain.ChainID))
+	}
+
+	verifyCtx, cancel := context.WithTimeout(ctx, chainVerifyTimeout)
+	defer cancel()
+	servedID, err := s.fetchChainID(verifyCtx, chain.RPCURL)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("RPC endpoint did not answer eth_chainId: %v", err))
+	}
+	if servedID != int64(chain.ChainID) {
+		return nil, errors.BadRequest(fmt.Sprintf("RPC endpoint serves chain %d, not %d", servedID, chain.ChainID))
+	}
+
+	if err := s.chainRepo.Create(ctx, chain); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if err := s.Load(ctx); err != nil {
+		logger.Error("Failed to reload custom chains", "error", err)
+	}
+
+	logger.Info("Registered custom chain", "chainId", chain.ChainID, "name", chain.Name)
+	return chain, nil
+}
+
+// DeleteChain removes a custom chain
+func (s *ChainService) DeleteChain(ctx context.Context, chainID int) error {
+	existing, err := s.chainRepo.GetByChainID(ctx, chainID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if existing == nil {
+		return errors.NotFound("Chain")
+	}
+
+	if err := s.chainRepo.Delete(ctx, chainID); err != nil {
+		return errors.DatabaseError(err)
+	}
+	if err := s.Load(ctx); err != nil {
+		logger.Error("Failed to reload custom chains", "error", err)
+	}
+	return nil
+}
+
+// validateCustomChain checks a registration request and returns the chain it describes
+func validateCustomChain(req *models.RegisterCustomChainRequest) (*models.CustomChain, *errors.AppError) {
+	if req.ChainID <= 0 {
+		return nil, errors.BadRequest("chain_id must be positive")
+	}
+	if blockchain.IsBuiltinChain(req.ChainID) {
+		return nil, errors.Conflict(fmt.Sprintf("chain %d is supported natively", req.ChainID))
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.BadRequest("name is required")
+	}
+	symbol := strings.ToUpper(strings.TrimSpace(req.NativeSymbol))
+	if symbol == "" {
+		return nil, errors.BadRequest("native_symbol is required")
+	}
+	if !isHTTPURL(req.RPCURL) {
+		return nil, errors.BadRequest("rpc_url must be an http or https URL")
+	}
+	if req.ExplorerURL != nil && !isHTTPURL(*req.ExplorerURL) {
+		return nil, errors.BadRequest("explorer_url must be an http or https URL")
+	}
+
+	chain := &models.CustomChain{
+		ChainID:      req.ChainID,
+		Name:         name,
+		RPCURL:       req.RPCURL,
+		ExplorerURL:  req.ExplorerURL,
+		NativeSymbol: symbol,
+		IsTestnet:    req.IsTestnet,
+	}
+	if req.NativeCoinGeckoID != nil && strings.TrimSpace(*req.NativeCoinGeckoID) != "" {
+		id := strings.ToLower(strings.TrimSpace(*req.NativeCoinGeckoID))
+		chain.NativeCoinGeckoID = &id
+	}
+	return chain, nil
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryChainRepo struct {
+	chains map[int]*models.CustomChain
+}
+
+func (r *memoryChainRepo) GetAll(ctx context.Context) ([]*models.CustomChain, error) {
+	var chains []*models.CustomChain
+	for _, chain := range r.chains {
+		chains = append(chains, chain)
+	}
+	return chains, nil
+}
+
+func (r *memoryChainRepo) GetByChainID(ctx context.Context, chainID int) (*models.CustomChain, error) {
+	return r.chains[chainID], nil
+}
+
+func (r *memoryChainRepo) Create(ctx context.Context, chain *models.CustomChain) error {
+	r.chains[chain.ChainID] = chain
+	return nil
+}
+
+func (r *memoryChainRepo) Delete(ctx context.Context, chainID int) error {
+	delete(r.chains, chainID)
+	return nil
+}
+
+func TestValidateCustomChain(t *testing.T) {
+	explorer := "https://explorer.mantle.xyz"
+	coinGeckoID := " Mantle "
+	chain, appErr := validateCustomChain(&models.RegisterCustomChainRequest{
+		ChainID: 5000, Name: " Mantle ", RPCURL: "https://rpc.mantle.xyz", ExplorerURL: &explorer,
+		NativeSymbol: "mnt", NativeCoinGeckoID: &coinGeckoID,
+	})
+	require.Nil(t, appErr)
+	assert.Equal(t, "Mantle", chain.Name)
+	assert.Equal(t, "MNT", chain.NativeSymbol)
+	assert.Equal(t, "mantle", *chain.NativeCoinGeckoID)
+
+	invalid := []models.RegisterCustomChainRequest{
+		{ChainID: 0, Name: "Zero", RPCURL: "https://rpc.example.com", NativeSymbol: "ETH"},
+		{ChainID: blockchain.ChainIDEthereum, Name: "Ethereum", RPCURL: "https://rpc.example.com", NativeSymbol: "ETH"},
+		{ChainID: 5000, Name: " ", RPCURL: "https://rpc.example.com", NativeSymbol: "MNT"},
+		{ChainID: 5000, Name: "Mantle", RPCURL: "https://rpc.example.com", NativeSymbol: ""},
+		{ChainID: 5000, Name: "Mantle", RPCURL: "ws://rpc.example.com", NativeSymbol: "MNT"},
+		{ChainID: 5000, Name: "Mantle", RPCURL: "rpc.example.com", NativeSymbol: "MNT"},
+	}
+	for _, req := range invalid {
+		_, appErr := validateCustomChain(&req)
+		assert.NotNil(t, appErr, "%+v", req)
+	}
+}
+
+func TestRegisterChainVerifiesRPCChainID(t *testing.T) {
+	repo := &memoryChainRepo{chains: make(map[int]*models.CustomChain)}
+	service := NewChainService(repo, nil)
+	service.fetchChainID = func(ctx context.Context, rpcURL string) (int64, error) { return 5000, nil }
+	defer blockchain.SetCustomChains(nil)
+
+	req := &models.RegisterCustomChainRequest{ChainID: 5001, Name: "Mantle Sepolia", RPCURL: "https://rpc.mantle.xyz", NativeSymbol: "MNT"}
+	_, err := service.RegisterChain(context.Background(), nil, req)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*errors.AppError).Status)
+	assert.Empty(t, repo.chains)
+
+	req.ChainID, req.Name = 5000, "Mantle"
+	chain, err := service.RegisterChain(context.Background(), nil, req)
+	require.NoError(t, err)
+	assert.Equal(t, 5000, chain.ChainID)
+	assert.Equal(t, "Mantle", blockchain.GetChainName(5000), "registering reloads the registry")
+
+	_, err = service.RegisterChain(context.Background(), nil, req)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, err.(*errors.AppError).Status)
+
+	require.NoError(t, service.DeleteChain(context.Background(), 5000))
+	assert.Equal(t, "Chain 5000", blockchain.GetChainName(5000))
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// FeatureFlagCompliance turns on counterparty screening and exposure reports when its
+// value has "enabled": true. It's meant for institutional deployments and off until an
+// admin sets it.
+const FeatureFlagCompliance = "compliance_screening"
+
+const (
+	// maxScreeningListAddresses caps the addresses a screening list holds
+	maxScreeningListAddresses = 50000
+	// maxFlaggedExposures caps the wallets listed in the admin exposure report
+	maxFlaggedExposures = 500
+)
+
+// exposureRank orders exposure levels by severity
+var exposureRank = map[string]int{
+	models.ExposureNone:   0,
+	models.ExposureLow:    1,
+	models.ExposureMedium: 2,
+	models.ExposureHigh:   3,
+}
+
+// ComplianceService screens the counterparties of tracked wallets against sanctions and
+// mixer address lists and reports each wallet's exposure
+type ComplianceService struct {
+	complianceRepo  repos.ComplianceRepository
+	featureFlagRepo repos.FeatureFlagRepository
+	clock           clock.Clock
+}
+
+func NewComplianceService(complianceRepo repos.ComplianceRepository, featureFlagRepo repos.FeatureFlagRepository) *ComplianceService {
+	return &ComplianceService{
+		complianceRepo:  complianceRepo,
+		featureFlagRepo: featureFlagRepo,
+		clock:           clock.System,
+	}
+}
+
+// Enabled reports whether an admin has turned compliance screening on
+func (s *ComplianceService) Enabled(ctx context.Context) bool {
+	flag, err := s.featureFlagRepo.GetByName(ctx, FeatureFlagCompliance)
+	if err != nil || flag == nil {
+		return false
+	}
+	enabled, _ := flag.Value["enabled"].(bool)
+	return enabled
+}
+
+// ExposureLevel rates a wallet's dealings with screened addresses. Sending funds to a
+// sanctioned address is high; sending to a mixer or receiving from a sanctioned address
+// is medium; receiving from a mixer, which anyone can do to anyone, is low.
+func ExposureLevel(hits []models.ExposureHit) string {
+	level := models.ExposureNone
+	for _, hit := range hits {
+		hitLevel := models.ExposureLow
+		switch {
+		case hit.Category == models.ScreeningCategorySanctions && hit.Direction == models.ExposureSent:
+			hitLevel = models.ExposureHigh
+		case hit.Category == models.ScreeningCategorySanctions, hit.Direction == models.ExposureSent:
+			hitLevel = models.ExposureMedium
+		}
+		if exposureRank[hitLevel] > exposureRank[level] {
+			level = hitLevel
+		}
+	}
+	return level
+}
+
+// Screen rates the exposure of every tracked wallet to the listed addresses and stores
+// it, returning how many wallets are exposed
+func (s *ComplianceService) Screen(ctx context.Context) (int, error) {
+	hits, err := s.complianceRepo.GetHits(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var exposures []*models.WalletExposure
+	byWallet := make(map[uuid.UUID]*models.WalletExposure)
+	for _, hit := range hits {
+		exposure, ok := byWallet[hit.WalletID]
+		if !ok {
+			exposure = &models.WalletExposure{WalletID: hit.WalletID}
+			byWallet[hit.WalletID] = exposure
+			exposures = append(exposures, exposure)
+		}
+		exposure.Hits = append(exposure.Hits, *hit)
+	}
+	for _, exposure := range exposures {
+		exposure.Level = ExposureLevel(exposure.Hits)
+	}
+
+	if err := s.complianceRepo.SaveExposures(ctx, exposures, s.clock.Now()); err != nil {
+		return 0, err
+	}
+	return len(exposures), nil
+}
+
+// GetReport returns the exposure of the user's wallets
+func (s *ComplianceService) GetReport(ctx context.Context, userID uuid.UUID) (*models.ExposureReport, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Compliance screening is disabled")
+	}
+
+	exposures, err := s.complianceRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return exposureReport(exposures), nil
+}
+
+// GetFlagged returns the wallets across users exposed at level or above
+func (s *ComplianceService) GetFlagged(ctx context.Context, level string) (*models.ExposureReport, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Compliance screening is disabled")
+	}
+	if level == "" {
+		level = models.ExposureMedium
+	}
+	minRank, ok := exposureRank[level]
+	if !ok || level == models.ExposureNone {
+		return nil, errors.BadRequest("level must be low, medium or high")
+	}
+
+	var levels []string
+	for l, rank := range exposureRank {
+		if rank >= minRank {
+			levels = append(levels, l)
+		}
+	}
+	exposures, err := s.complianceRepo.GetFlagged(ctx, levels, maxFlaggedExposures)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return exposureReport(exposures), nil
+}
+
+func exposureReport(exposures []*models.WalletExposure) *models.ExposureReport {
+	report := &models.ExposureReport{Level: models.ExposureNone, Wallets: exposures}
+	for _, exposure := range exposures {
+		if exposureRank[exposure.Level] > exposureRank[report.Level] {
+			report.Level = exposure.Level
+		}
+	}
+	return report
+}
+
+// GetLists returns the screening lists
+func (s *ComplianceService) GetLists(ctx context.Context) ([]*models.ScreeningList, error) {
+	lists, err := s.complianceRepo.GetLists(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return lists, nil
+}
+
+// CreateList adds a screening list; the screening job picks it up on its next run
+func (s *ComplianceService) CreateList(ctx context.Context, req *models.CreateScreeningListRequest) (*models.ScreeningList, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, errors.BadRequest("Name is required and must be at most 100 characters")
+	}
+	if req.Category != models.ScreeningCategorySanctions && req.Category != models.ScreeningCategoryMixer {
+		return nil, errors.BadRequest("Category must be sanctions or mixer")
+	}
+	addresses, appErr := parseScreeningAddresses(req.Addresses)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	list := &models.ScreeningList{Name: name, Category: req.Category}
+	if err := s.complianceRepo.CreateList(ctx, list, addresses); err != nil {
+		if err == repos.ErrScreeningListExists {
+			return nil, errors.Conflict("A screening list with this name already exists")
+		}
+		return nil, errors.DatabaseError(err)
+	}
+	logger.Info("Screening list created", "list", list.Name, "category", list.Category, "addresses", len(addresses))
+	return list, nil
+}
+
+// SetListAddresses replaces a screening list's addresses
+func (s *ComplianceService) SetListAddresses(ctx context.Context, listID uuid.UUID, req *models.SetScreeningListAddressesRequest) error {
+	addresses, appErr := parseScreeningAddresses(req.Addresses)
+	if appErr != nil {
+		return appErr
+	}
+
+	ok, err := s.complianceRepo.SetAddresses(ctx, listID, addresses)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !ok {
+		return errors.NotFound("Screening list")
+	}
+	logger.Info("Screening list addresses replaced", "listID", listID, "addresses", len(addresses))
+	return nil
+}
+
+// DeleteList removes a screening list
+func (s *ComplianceService) DeleteList(ctx context.Context, listID uuid.UUID) error {
+	ok, err := s.complianceRepo.DeleteList(ctx, listID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !ok {
+		return errors.NotFound("Screening list")
+	}
+	return nil
+}
+
+// parseScreeningAddresses validates a list's EVM addresses, returning their stored forms
+func parseScreeningAddresses(addresses []string) ([]string, *errors.AppError) {
+	if len(addresses) > maxScreeningListAddresses {
+		return nil, errors.BadRequest("A screening list can hold at most 50000 addresses")
+	}
+	parsed := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		p, err := address.Parse(a)
+		if err != nil {
+			return nil, errors.BadRequest(err.Error())
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed, nil
+}
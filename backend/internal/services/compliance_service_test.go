@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryComplianceRepo struct {
+	repos.ComplianceRepository
+	hits    []*models.ExposureHit
+	saved   []*models.WalletExposure
+	savedAt time.Time
+}
+
+func (r *memoryComplianceRepo) GetHits(ctx context.Context) ([]*models.ExposureHit, error) {
+	return r.hits, nil
+}
+
+func (r *memoryComplianceRepo) SaveExposures(ctx context.Context, exposures []*models.WalletExposure, at time.Time) error {
+	r.saved, r.savedAt = exposures, at
+	return nil
+}
+
+func (r *memoryComplianceRepo) GetByUser(ctx context.Context, userID uuid.UUID) ([]*models.WalletExposure, error) {
+	return r.saved, nil
+}
+
+func TestExposureLevel(t *testing.T) {
+	hit := func(category, direction string) models.ExposureHit {
+		return models.ExposureHit{Category: category, Direction: direction}
+	}
+	mixerIn := hit(models.ScreeningCategoryMixer, models.ExposureReceived)
+	mixerOut := hit(models.ScreeningCategoryMixer, models.ExposureSent)
+	sanctionedIn := hit(models.ScreeningCategorySanctions, models.ExposureReceived)
+	sanctionedOut := hit(models.ScreeningCategorySanctions, models.ExposureSent)
+
+	assert.Equal(t, models.ExposureNone, ExposureLevel(nil))
+	assert.Equal(t, models.ExposureLow, ExposureLevel([]models.ExposureHit{mixerIn}))
+	assert.Equal(t, models.ExposureMedium, ExposureLevel([]models.ExposureHit{mixerIn, mixerOut}))
+	assert.Equal(t, models.ExposureMedium, ExposureLevel([]models.ExposureHit{sanctionedIn}))
+	assert.Equal(t, models.ExposureHigh, ExposureLevel([]models.ExposureHit{sanctionedOut, mixerIn}))
+}
+
+func TestComplianceServiceScreen(t *testing.T) {
+	exposed, dusted := uuid.New(), uuid.New()
+	repo := &memoryComplianceRepo{hits: []*models.ExposureHit{
+		{WalletID: exposed, Address: "0xd90e2f925da726b50c4ed8d0fb90ad053324f31b", Category: models.ScreeningCategoryMixer, Direction: models.ExposureSent, TxCount: 2},
+		{WalletID: exposed, Address: "0x12d66f87a04a9e220743712ce6d9bb1b5616b8fc", Category: models.ScreeningCategoryMixer, Direction: models.ExposureReceived, TxCount: 1},
+		{WalletID: dusted, Address: "0x12d66f87a04a9e220743712ce6d9bb1b5616b8fc", Category: models.ScreeningCategoryMixer, Direction: models.ExposureReceived, TxCount: 1},
+	}}
+	flags := &memoryFeatureFlagRepo{flags: map[string]*models.FeatureFlag{}}
+	service := NewComplianceService(repo, flags)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.clock = clock.NewFake(now)
+	ctx := context.Background()
+
+	count, err := service.Screen(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, now, repo.savedAt)
+	require.Len(t, repo.saved, 2)
+	assert.Equal(t, exposed, repo.saved[0].WalletID)
+	assert.Equal(t, models.ExposureMedium, repo.saved[0].Level)
+	assert.Len(t, repo.saved[0].Hits, 2)
+	assert.Equal(t, models.ExposureLow, repo.saved[1].Level)
+
+	// Reports are only served while the feature is on
+	_, err = service.GetReport(ctx, uuid.New())
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, 403, appErr.Status)
+
+	flags.flags[FeatureFlagCompliance] = &models.FeatureFlag{Name: FeatureFlagCompliance, Value: map[string]interface{}{"enabled": true}}
+	report, err := service.GetReport(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, models.ExposureMedium, report.Level)
+	assert.Len(t, report.Wallets, 2)
+}
+
+func TestComplianceServiceCreateListValidates(t *testing.T) {
+	service := NewComplianceService(&memoryComplianceRepo{}, &memoryFeatureFlagRepo{})
+	ctx := context.Background()
+
+	for _, req := range []*models.CreateScreeningListRequest{
+		{Name: "", Category: models.ScreeningCategoryMixer},
+		{Name: "OFAC", Category: "exchange"},
+		{Name: "OFAC", Category: models.ScreeningCategorySanctions, Addresses: []string{"0x1234"}},
+	} {
+		_, err := service.CreateList(ctx, req)
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok, "%v", err)
+		assert.Equal(t, 400, appErr.Status)
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+)
+
+// RiskySecurityScore is the security score below which a contract is called out, as
+// a spender on approval alerts
+const RiskySecurityScore = 60
+
+// newContractAge is how long after deployment a contract counts as new
+const newContractAge = 30 * 24 * time.Hour
+
+// contractFlagPenalties is how much each risk flag lowers a contract's security score.
+// An unverified contract, or an address that isn't one, is risky on its own.
+var contractFlagPenalties = map[string]int{
+	models.ContractFlagNotContract:              60,
+	models.ContractFlagUnverified:               45,
+	models.ContractFlagUnverifiedImplementation: 45,
+	models.ContractFlagNew:                      25,
+	models.ContractFlagProxy:                    10,
+}
+
+// ScoreContract sets a contract's risk flags and the security score they leave of 100
+func ScoreContract(info *models.ContractInfo, now time.Time) {
+	var flags []string
+	switch {
+	case !info.IsContract:
+		flags = append(flags, models.ContractFlagNotContract)
+	case !info.Verified:
+		flags = append(flags, models.ContractFlagUnverified)
+	}
+	if info.IsProxy {
+		// An upgradeable contract can change under an allowance
+		flags = append(flags, models.ContractFlagProxy)
+		if info.ImplementationVerified != nil && !*info.ImplementationVerified {
+			flags = append(flags, models.ContractFlagUnverifiedImplementation)
+		}
+	}
+	if info.DeployedAt != nil && now.Sub(*info.DeployedAt) < newContractAge {
+		flags = append(flags, models.ContractFlagNew)
+	}
+
+	score := 100
+	for _, flag := range flags {
+		score -= contractFlagPenalties[flag]
+	}
+	if score < 0 {
+		score = 0
+	}
+	info.SecurityScore = score
+	info.RiskFlags = flags
+	if info.RiskFlags == nil {
+		info.RiskFlags = []string{}
+	}
+}
+
+// ContractService serves the contracts the worker's contract-metadata job looked up,
+// scored for security
+type ContractService struct {
+	contractRepo repos.ContractRepository
+	clock        clock.Clock
+}
+
+func NewContractService(contractRepo repos.ContractRepository) *ContractService {
+	return &ContractService{
+		contractRepo: contractRepo,
+		clock:        clock.System,
+	}
+}
+
+// Lookup returns the contracts looked up among addresses on a chain, keyed by
+// lowercase address
+func (s *ContractService) Lookup(ctx context.Context, chainID int, addresses []string) (map[string]*models.ContractInfo, error) {
+	contracts, err := s.contractRepo.GetMany(ctx, chainID, addresses)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	for _, info := range contracts {
+		ScoreContract(info, now)
+	}
+	return contracts, nil
+}
+
+// Get returns the contract at an address on a chain, or nil when it wasn't looked up
+func (s *ContractService) Get(ctx context.Context, chainID int, address string) (*models.ContractInfo, error) {
+	contracts, err := s.Lookup(ctx, chainID, []string{address})
+	if err != nil {
+		return nil, err
+	}
+	return contracts[strings.ToLower(address)], nil
+}
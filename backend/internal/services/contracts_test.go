@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreContract(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	old, recent := now.AddDate(-2, 0, 0), now.AddDate(0, 0, -3)
+	verified, unverified := true, false
+
+	tests := []struct {
+		name  string
+		info  models.ContractInfo
+		score int
+		flags []string
+	}{
+		{"verified", models.ContractInfo{IsContract: true, Verified: true, DeployedAt: &old}, 100, []string{}},
+		{"unverified", models.ContractInfo{IsContract: true, DeployedAt: &old}, 55,
+			[]string{models.ContractFlagUnverified}},
+		{"wallet", models.ContractInfo{}, 40, []string{models.ContractFlagNotContract}},
+		{"proxy", models.ContractInfo{IsContract: true, Verified: true, IsProxy: true, ImplementationVerified: &verified, DeployedAt: &old}, 90,
+			[]string{models.ContractFlagProxy}},
+		{"proxy to unverified code", models.ContractInfo{IsContract: true, Verified: true, IsProxy: true, ImplementationVerified: &unverified}, 45,
+			[]string{models.ContractFlagProxy, models.ContractFlagUnverifiedImplementation}},
+		{"new and unverified", models.ContractInfo{IsContract: true, DeployedAt: &recent}, 30,
+			[]string{models.ContractFlagUnverified, models.ContractFlagNew}},
+	}
+	for _, tt := range tests {
+		info := tt.info
+		ScoreContract(&info, now)
+		assert.Equal(t, tt.score, info.SecurityScore, tt.name)
+		assert.Equal(t, tt.flags, info.RiskFlags, tt.name)
+	}
+	assert.Less(t, 55, RiskySecurityScore, "unverified contracts are risky on their own")
+}
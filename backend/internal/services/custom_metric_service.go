@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxCustomMetrics caps how many metrics one user can define
+	maxCustomMetrics = 20
+	// maxCustomMetricName is the longest name a metric can have, in characters
+	maxCustomMetricName = 100
+	// customMetricCacheTTL is how long a computed value is shown before the widgets
+	// compute it again
+	customMetricCacheTTL = 5 * time.Minute
+)
+
+// CustomMetricService keeps the metrics users define over their portfolio with the
+// expression language of parseMetricExpr, and computes them for their dashboard widgets.
+// Values are computed from the stored balances and positions and cached with the metric
+// for customMetricCacheTTL.
+type CustomMetricService struct {
+	repo  repos.CustomMetricRepository
+	clock clock.Clock
+}
+
+func NewCustomMetricService(repo repos.CustomMetricRepository) *CustomMetricService {
+	return &CustomMetricService{repo: repo, clock: clock.System}
+}
+
+// Variables lists the variables and functions expressions can use
+func (s *CustomMetricService) Variables() *models.MetricVariables {
+	return &models.MetricVariables{Variables: metricVariables, Functions: metricFunctionDocs}
+}
+
+func (s *CustomMetricService) Create(ctx context.Context, userID uuid.UUID, req *models.CreateCustomMetricRequest) (*models.CustomMetric, error) {
+	name, err := validateCustomMetricName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseMetricExpr(req.Expression); err != nil {
+		return nil, err
+	}
+	format, err := validateMetricFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if len(existing) >= maxCustomMetrics {
+		return nil, errors.BadRequest("Custom metric limit reached; delete one to add another")
+	}
+
+	metric := &models.CustomMetric{
+		UserID:     userID,
+		Name:       name,
+		Expression: strings.TrimSpace(req.Expression),
+		Format:     format,
+		Position:   req.Position,
+	}
+	if err := s.repo.Create(ctx, metric); err != nil {
+		return nil, customMetricSaveError(err)
+	}
+	return metric, nil
+}
+
+// List returns the user's metrics with their last computed values, whether stale or not
+func (s *CustomMetricService) List(ctx context.Context, userID uuid.UUID) ([]*models.CustomMetric, error) {
+	metrics, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return metrics, nil
+}
+
+func (s *CustomMetricService) Get(ctx context.Context, userID, id uuid.UUID) (*models.CustomMetric, error) {
+	metric, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if metric == nil || metric.UserID != userID {
+		return nil, errors.NotFound("Custom metric")
+	}
+	return metric, nil
+}
+
+func (s *CustomMetricService) Update(ctx context.Context, userID, id uuid.UUID, req *models.UpdateCustomMetricRequest) (*models.CustomMetric, error) {
+	metric, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if metric.Name, err = validateCustomMetricName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Expression != nil {
+		if _, err := parseMetricExpr(*req.Expression); err != nil {
+			return nil, err
+		}
+		metric.Expression = strings.TrimSpace(*req.Expression)
+	}
+	if req.Format != nil {
+		if metric.Format, err = validateMetricFormat(*req.Format); err != nil {
+			return nil, err
+		}
+	}
+	if req.Position != nil {
+		metric.Position = *req.Position
+	}
+
+	if err := s.repo.Update(ctx, metric); err != nil {
+		return nil, customMetricSaveError(err)
+	}
+	return metric, nil
+}
+
+func (s *CustomMetricService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	deleted, err := s.repo.Delete(ctx, id, userID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Custom metric")
+	}
+	return nil
+}
+
+// Widgets returns the user's metrics for their dashboard tiles, computing those not
+// computed in the last customMetricCacheTTL, or all of them when refresh is set
+func (s *CustomMetricService) Widgets(ctx context.Context, userID uuid.UUID, refresh bool) ([]*models.CustomMetric, error) {
+	metrics, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	now := s.clock.Now()
+	var env *metricEnv
+	for _, metric := range metrics {
+		if !refresh && metric.ComputedAt != nil && now.Sub(*metric.ComputedAt) < customMetricCacheTTL {
+			continue
+		}
+		if env == nil {
+			holdings, err := s.repo.GetHoldings(ctx, userID)
+			if err != nil {
+				return nil, errors.DatabaseError(err)
+			}
+			env = newMetricEnv(holdings)
+		}
+
+		metric.Value, metric.Error = evalCustomMetric(metric.Expression, env)
+		computedAt := now
+		metric.ComputedAt = &computedAt
+		if err := s.repo.SaveValue(ctx, metric.ID, metric.Value, metric.Error, now); err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+	}
+	return metrics, nil
+}
+
+// evalCustomMetric computes a stored expression, returning its value or why there's none
+func evalCustomMetric(expression string, env *metricEnv) (*float64, *string) {
+	expr, err := parseMetricExpr(expression)
+	if err != nil {
+		// Expressions are checked when saved, so this only follows a change to the language
+		msg := "expression is no longer valid"
+		return nil, &msg
+	}
+	value, err := expr.eval(env)
+	if err != nil {
+		msg := err.Error()
+		return nil, &msg
+	}
+	return &value, nil
+}
+
+// newMetricEnv sums the holdings into the metricVariables and the value per symbol
+func newMetricEnv(holdings []*models.MetricHolding) *metricEnv {
+	env := &metricEnv{vars: make(map[string]float64, len(metricVariables)), values: make(map[string]float64)}
+	tokens := make(map[string]bool)
+	for _, h := range holdings {
+		env.vars["total_value_usd"] += h.ValueUSD
+		env.values[h.Symbol] += h.ValueUSD
+		if h.Stablecoin || external.IsUSDAsset(h.Symbol) {
+			env.vars["stablecoin_value_usd"] += h.ValueUSD
+		}
+		if !h.Position {
+			env.vars["wallet_value_usd"] += h.ValueUSD
+			tokens[h.Symbol] = true
+			continue
+		}
+		env.vars["positions_value_usd"] += h.ValueUSD
+		env.vars["position_count"]++
+		if h.APY != nil {
+			env.vars["apy_weighted_usd"] += h.ValueUSD * *h.APY / 100
+		}
+	}
+	env.vars["token_count"] = float64(len(tokens))
+	return env
+}
+
+func validateCustomMetricName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.BadRequest("Name is required")
+	}
+	if utf8.RuneCountInString(name) > maxCustomMetricName {
+		return "", errors.BadRequest("Name must be at most 100 characters")
+	}
+	return name, nil
+}
+
+func validateMetricFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return models.MetricFormatNumber, nil
+	case models.MetricFormatUSD, models.MetricFormatPercent, models.MetricFormatNumber:
+		return format, nil
+	default:
+		return "", errors.BadRequest("Format must be usd, percent or number")
+	}
+}
+
+func customMetricSaveError(err error) error {
+	if err == repos.ErrCustomMetricNameTaken {
+		return errors.New("CUSTOM_METRIC_EXISTS", "A custom metric with this name already exists", 409)
+	}
+	return errors.DatabaseError(err)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCustomMetrics keeps metrics in memory and counts the holdings reads
+type memoryCustomMetrics struct {
+	repos.CustomMetricRepository
+	metrics      []*models.CustomMetric
+	holdings     []*models.MetricHolding
+	holdingReads int
+}
+
+func (r *memoryCustomMetrics) Create(_ context.Context, metric *models.CustomMetric) error {
+	for _, m := range r.metrics {
+		if m.UserID == metric.UserID && m.Name == metric.Name {
+			return repos.ErrCustomMetricNameTaken
+		}
+	}
+	metric.ID = uuid.New()
+	r.metrics = append(r.metrics, metric)
+	return nil
+}
+
+func (r *memoryCustomMetrics) GetByUser(_ context.Context, userID uuid.UUID) ([]*models.CustomMetric, error) {
+	var metrics []*models.CustomMetric
+	for _, m := range r.metrics {
+		if m.UserID == userID {
+			copied := *m
+			metrics = append(metrics, &copied)
+		}
+	}
+	return metrics, nil
+}
+
+func (r *memoryCustomMetrics) SaveValue(_ context.Context, id uuid.UUID, value *float64, evalErr *string, at time.Time) error {
+	for _, m := range r.metrics {
+		if m.ID == id {
+			m.Value, m.Error, m.ComputedAt = value, evalErr, &at
+		}
+	}
+	return nil
+}
+
+func (r *memoryCustomMetrics) GetHoldings(_ context.Context, _ uuid.UUID) ([]*models.MetricHolding, error) {
+	r.holdingReads++
+	return r.holdings, nil
+}
+
+func TestCustomMetricServiceCreate(t *testing.T) {
+	repo := &memoryCustomMetrics{}
+	service := NewCustomMetricService(repo)
+	userID := uuid.New()
+
+	metric, err := service.Create(context.Background(), userID, &models.CreateCustomMetricRequest{
+		Name:       " Stablecoin share ",
+		Expression: "stablecoin_value_usd / total_value_usd * 100",
+		Format:     models.MetricFormatPercent,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Stablecoin share", metric.Name)
+
+	_, err = service.Create(context.Background(), userID, &models.CreateCustomMetricRequest{
+		Name: "Stablecoin share", Expression: "total_value_usd",
+	})
+	require.Error(t, err)
+	assert.Equal(t, 409, err.(*errors.AppError).Status)
+
+	_, err = service.Create(context.Background(), userID, &models.CreateCustomMetricRequest{
+		Name: "Broken", Expression: "total_value_usd *",
+	})
+	require.Error(t, err)
+	assert.Equal(t, 400, err.(*errors.AppError).Status)
+
+	_, err = service.Create(context.Background(), userID, &models.CreateCustomMetricRequest{
+		Name: "Odd format", Expression: "1", Format: "btc",
+	})
+	require.Error(t, err)
+	assert.Equal(t, 400, err.(*errors.AppError).Status)
+
+	metric, err = service.Create(context.Background(), userID, &models.CreateCustomMetricRequest{
+		Name: "Tokens", Expression: "token_count",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.MetricFormatNumber, metric.Format, "the format defaults to number")
+}
+
+func TestCustomMetricServiceWidgetsComputeAndCache(t *testing.T) {
+	apy := 8.0
+	repo := &memoryCustomMetrics{holdings: []*models.MetricHolding{
+		{Symbol: "ETH", ValueUSD: 600},
+		{Symbol: "USDC", ValueUSD: 200},
+		{Symbol: "GHO", ValueUSD: 50, Stablecoin: true},
+		{Symbol: "USDC-USDT", ValueUSD: 150, Stablecoin: true, Position: true, APY: &apy},
+	}}
+	service := NewCustomMetricService(repo)
+	clk := clock.NewFake(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	service.clock = clk
+	userID := uuid.New()
+
+	for _, req := range []models.CreateCustomMetricRequest{
+		{Name: "Stablecoin share", Expression: "stablecoin_value_usd / total_value_usd * 100"},
+		{Name: "Yearly yield", Expression: "apy_weighted_usd"},
+		{Name: "Average APY", Expression: "apy_weighted_usd / positions_value_usd * 100"},
+		{Name: "Tokens", Expression: "token_count + position_count"},
+	} {
+		_, err := service.Create(context.Background(), userID, &req)
+		require.NoError(t, err)
+	}
+
+	widgets, err := service.Widgets(context.Background(), userID, false)
+	require.NoError(t, err)
+	require.Len(t, widgets, 4)
+	assert.InDelta(t, 40, *widgets[0].Value, 1e-9)
+	assert.InDelta(t, 12, *widgets[1].Value, 1e-9)
+	assert.InDelta(t, 8, *widgets[2].Value, 1e-9)
+	assert.InDelta(t, 4, *widgets[3].Value, 1e-9)
+	assert.Equal(t, 1, repo.holdingReads, "one read of the holdings serves every metric")
+
+	// Cached values are served until they go stale
+	repo.holdings = nil
+	clk.Advance(time.Minute)
+	widgets, err = service.Widgets(context.Background(), userID, false)
+	require.NoError(t, err)
+	assert.InDelta(t, 40, *widgets[0].Value, 1e-9)
+	assert.Equal(t, 1, repo.holdingReads)
+
+	clk.Advance(customMetricCacheTTL)
+	widgets, err = service.Widgets(context.Background(), userID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.holdingReads)
+	assert.Nil(t, widgets[0].Value, "nothing held divides by a zero total")
+	require.NotNil(t, widgets[0].Error)
+	assert.Equal(t, "division by zero", *widgets[0].Error)
+	assert.InDelta(t, 0, *widgets[1].Value, 1e-9)
+
+	_, err = service.Widgets(context.Background(), userID, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, repo.holdingReads, "refresh computes cached values again")
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type DebtPositionService struct {
+	walletRepo repos.WalletRepository
+	// readPositions reads a wallet's lending positions on chain; replaced in tests
+	readPositions func(ctx context.Context, alchemyAPIKey, address string, chainID int) ([]*models.DebtPosition, error)
+}
+
+func NewDebtPositionService(walletRepo repos.WalletRepository) *DebtPositionService {
+	return &DebtPositionService{
+		walletRepo: walletRepo,
+		readPositions: func(ctx context.Context, alchemyAPIKey, address string, chainID int) ([]*models.DebtPosition, error) {
+			return blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "").GetDebtPositions(ctx, address, chainID)
+		},
+	}
+}
+
+// GetPositions returns open Aave/Compound borrow positions across the user's wallets,
+// riskiest (lowest health factor) first. address narrows the result to one wallet.
+func (s *DebtPositionService) GetPositions(ctx context.Context, userID uuid.UUID, address string, alchemyAPIKey string) ([]*models.DebtPosition, error) {
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+
+	var targets []*models.Wallet
+	for _, wallet := range wallets {
+		if address != "" && !strings.EqualFold(wallet.Address, address) {
+			continue
+		}
+		if blockchain.SupportsDebtPositions(wallet.ChainID) {
+			targets = append(targets, wallet)
+		}
+	}
+	if address != "" && len(targets) == 0 {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		positions = []*models.DebtPosition{}
+		failed    int
+	)
+	for _, wallet := range targets {
+		wg.Add(1)
+		go func(wallet *models.Wallet) {
+			defer wg.Done()
+			walletPositions, err := s.readPositions(ctx, alchemyAPIKey, wallet.Address, wallet.ChainID)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Error("Failed to read debt positions", "error", err, "address", wallet.Address, "chainID", wallet.ChainID)
+				failed++
+				return
+			}
+			positions = append(positions, walletPositions...)
+		}(wallet)
+	}
+	wg.Wait()
+
+	if failed > 0 && failed == len(targets) {
+		return nil, errors.Internal("Failed to read lending positions")
+	}
+
+	sortByHealthFactor(positions)
+	return positions, nil
+}
+
+func sortByHealthFactor(positions []*models.DebtPosition) {
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].HealthFactor < positions[j].HealthFactor
+	})
+}
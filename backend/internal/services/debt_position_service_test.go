@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebtPositionServiceGetPositions(t *testing.T) {
+	userID := uuid.New()
+	const (
+		mainnet = "0x1111111111111111111111111111111111111111"
+		polygon = "0x2222222222222222222222222222222222222222"
+		solana  = "7xKXtg2CW87d97TXJSDpbD5jBkheTqA83TZRuJosgAsU"
+	)
+	wallets := &allowanceWallets{wallets: []*models.Wallet{
+		{UserID: userID, Address: mainnet, ChainID: 1},
+		{UserID: userID, Address: polygon, ChainID: 137},
+		{UserID: userID, Address: solana, ChainID: 101},
+		{UserID: uuid.New(), Address: "0x3333333333333333333333333333333333333333", ChainID: 1},
+	}}
+
+	var (
+		mu      sync.Mutex
+		read    []string
+		failing = map[string]bool{}
+	)
+	service := NewDebtPositionService(wallets)
+	service.readPositions = func(_ context.Context, _ string, address string, chainID int) ([]*models.DebtPosition, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		read = append(read, address)
+		if failing[address] {
+			return nil, stderrors.New("rpc unavailable")
+		}
+		if address == mainnet {
+			return []*models.DebtPosition{
+				{Protocol: models.DebtProtocolAaveV3, ChainID: chainID, WalletAddress: address, HealthFactor: 2.1},
+				{Protocol: models.DebtProtocolCompoundV3, ChainID: chainID, WalletAddress: address, HealthFactor: 1.3},
+			}, nil
+		}
+		return []*models.DebtPosition{{Protocol: models.DebtProtocolAaveV3, ChainID: chainID, WalletAddress: address, HealthFactor: 1.7}}, nil
+	}
+
+	// Only the user's wallets on chains with lending markets are read, riskiest first
+	positions, err := service.GetPositions(context.Background(), userID, "", "key")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{mainnet, polygon}, read)
+	require.Len(t, positions, 3)
+	assert.Equal(t, []float64{1.3, 1.7, 2.1}, []float64{positions[0].HealthFactor, positions[1].HealthFactor, positions[2].HealthFactor})
+
+	// The address filter is case-insensitive
+	read = nil
+	positions, err = service.GetPositions(context.Background(), userID, "0x"+strings.ToUpper(polygon[2:]), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []string{polygon}, read)
+	assert.Len(t, positions, 1)
+
+	// Wallets that aren't the user's, or have no lending markets, aren't found
+	_, err = service.GetPositions(context.Background(), userID, "0x3333333333333333333333333333333333333333", "key")
+	require.Error(t, err)
+	assert.Equal(t, 404, err.(*errors.AppError).Status)
+	_, err = service.GetPositions(context.Background(), userID, solana, "key")
+	require.Error(t, err)
+	assert.Equal(t, 404, err.(*errors.AppError).Status)
+
+	// A wallet that can't be read is left out, unless none could be read
+	failing[polygon] = true
+	positions, err = service.GetPositions(context.Background(), userID, "", "key")
+	require.NoError(t, err)
+	assert.Len(t, positions, 2)
+
+	failing[mainnet] = true
+	_, err = service.GetPositions(context.Background(), userID, "", "key")
+	require.Error(t, err)
+	assert.Equal(t, 500, err.(*errors.AppError).Status)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// destinationGasUSD is how much of a transfer a refuel leg turns into the destination
+// chain's gas token: enough for a handful of transactions on an L2, or a couple on mainnet
+const destinationGasUSD = 5.0
+
+// NativeBalanceReader reads an address's balance of a chain's gas token
+type NativeBalanceReader interface {
+	GetNativeBalance(ctx context.Context, chainID int, address string) (*big.Int, error)
+}
+
+// PlatformNativeBalances reads native balances with the platform's provider keys, as
+// quote requests bring none. Rotated keys apply to the next read.
+type PlatformNativeBalances struct{}
+
+func (PlatformNativeBalances) GetNativeBalance(ctx context.Context, chainID int, address string) (*big.Int, error) {
+	return blockchain.NewBlockchainServiceWithDynamicKeys("", "").GetNativeBalance(ctx, chainID, address)
+}
+
+// DestinationGas is set on routes that would leave the user on a chain where they hold
+// none of its gas token, so they couldn't move what arrives
+type DestinationGas struct {
+	Token  string `json:"token"`
+	Symbol string `json:"symbol"`
+	// Refuel is the leg delivering gas alongside the transfer; it's missing when the
+	// request didn't ask for one or the provider couldn't include it
+	Refuel *RefuelLeg `json:"refuel,omitempty"`
+}
+
+// RefuelLeg swaps part of a transfer into the destination chain's gas token
+type RefuelLeg struct {
+	Protocol   string `json:"protocol"`
+	FromToken  string `json:"fromToken"`
+	FromAmount string `json:"fromAmount"`
+	// ToAmount is the gas token delivered, in its base units
+	ToAmount string `json:"toAmount"`
+}
+
+// destinationGasPlan is what a route request needs for gas on the destination chain
+type destinationGasPlan struct {
+	token  string
+	symbol string
+	// fromAmountForGas is the part of the transfer providers are asked to refuel with;
+	// empty when no refuel leg is asked for
+	fromAmountForGas string
+}
+
+// SetNativeBalances lets route requests check the user has gas on the destination chain;
+// without it routes aren't annotated and no refuel legs are asked for
+func (s *BridgeService) SetNativeBalances(balances NativeBalanceReader) {
+	s.balances = balances
+}
+
+// planDestinationGas returns nil unless the user holds none of the destination chain's
+// gas token and the transfer doesn't deliver it. A failed balance read is taken as gas
+// held, so quotes don't fail for it.
+func (s *BridgeService) planDestinationGas(ctx context.Context, req BridgeRouteRequest) *destinationGasPlan {
+	if s.balances == nil || quoteTokenAddress(req.ToToken) == blockchain.NativeTokenAddress {
+		return nil
+	}
+	balance, err := s.balances.GetNativeBalance(ctx, req.ToChain, req.UserAddress)
+	if err != nil {
+		logger.Warn("Failed to check destination gas", "chainId", req.ToChain, "error", err)
+		return nil
+	}
+	if balance.Sign() > 0 {
+		return nil
+	}
+
+	native := blockchain.NativeToken(req.ToChain)
+	plan := &destinationGasPlan{token: native.Address, symbol: native.Symbol}
+	if req.WantsDestinationGas {
+		plan.fromAmountForGas = s.refuelAmount(ctx, req)
+	}
+	return plan
+}
+
+// refuelAmount is destinationGasUSD of the transfer's token, in base units. It's empty
+// when the token has no price or the refuel would take more than half the transfer.
+func (s *BridgeService) refuelAmount(ctx context.Context, req BridgeRouteRequest) string {
+	if s.prices == nil {
+		return ""
+	}
+	address := quoteTokenAddress(req.FromToken)
+	tokens, err := s.prices.GetTokensByAddresses(ctx, req.FromChain, []string{address})
+	if err != nil {
+		logger.Warn("Failed to price refuel", "chainId", req.FromChain, "error", err)
+		return ""
+	}
+	token := tokens[address]
+	if token == nil || token.PriceUSD == nil || *token.PriceUSD <= 0 {
+		return ""
+	}
+
+	units := decimal.NewFromFloat(destinationGasUSD).DivRound(decimal.NewFromFloat(*token.PriceUSD), int32(token.Decimals))
+	refuel, err := amounts.FromUnits(units, token.Decimals)
+	if err != nil || refuel.Sign() == 0 {
+		return ""
+	}
+	from, err := amounts.ParseBaseUnits(req.FromAmount)
+	if err != nil || new(big.Int).Mul(refuel, big.NewInt(2)).Cmp(from) > 0 {
+		return ""
+	}
+	return refuel.String()
+}
+
+// annotate returns a route's destination gas, with the quote's refuel leg if it has one
+func (p *destinationGasPlan) annotate(quote clients.Quote) *DestinationGas {
+	if p == nil {
+		return nil
+	}
+	gas := &DestinationGas{Token: p.token, Symbol: p.symbol}
+	if quote.Refuel != nil {
+		gas.Refuel = &RefuelLeg{
+			Protocol:   quote.Refuel.Protocol,
+			FromToken:  quote.Refuel.FromToken.Address,
+			FromAmount: quote.Refuel.FromAmount,
+			ToAmount:   quote.Refuel.ToAmount,
+		}
+	}
+	return gas
+}
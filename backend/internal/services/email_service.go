@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/email"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// Email templates, named after their files in pkg/email/templates
+const (
+	emailTemplateAlert               = "alert"
+	emailTemplateStatement           = "statement"
+	emailTemplateMigration           = "pool_migration"
+	emailTemplateBroadcast           = "broadcast"
+	emailTemplateWebhookReportFailed = "webhook_report_failed"
+)
+
+// emailDeliveryLimit caps how many deliveries to one address are listed
+const emailDeliveryLimit = 100
+
+// emailRetryDelays are the waits before retrying a send the provider failed for a
+// reason that may pass, such as throttling. Longer outages are left to the callers'
+// own retries, like the notification queue's.
+var emailRetryDelays = []time.Duration{time.Second, 5 * time.Second}
+
+// EmailService renders and sends the emails users get, through whichever provider is
+// configured. Every email is recorded with its outcome, and addresses that hard bounce
+// or complain are suppressed, with email notifications turned off for their users.
+// It is the EmailSender and StatementMailer of alert notifications and statements.
+type EmailService struct {
+	sender      email.Sender
+	emailRepo   repos.EmailRepository
+	httpClient  *http.Client
+	retryDelays []time.Duration
+}
+
+func NewEmailService(sender email.Sender, emailRepo repos.EmailRepository) *EmailService {
+	return &EmailService{
+		sender:      sender,
+		emailRepo:   emailRepo,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		retryDelays: emailRetryDelays,
+	}
+}
+
+// SendAlertEmail emails a triggered alert, one line per triggered value
+func (s *EmailService) SendAlertEmail(ctx context.Context, to string, message models.AlertNotificationMessage) error {
+	text := message.Text
+	if text == "" {
+		text = notificationText(message, true)
+	}
+	lines := strings.Split(text, "\n")
+	return s.send(ctx, emailTemplateAlert, to, map[string]interface{}{
+		"Headline":    lines[0],
+		"Details":     lines[1:],
+		"TriggeredAt": message.TriggeredAt,
+	})
+}
+
+// SendStatementEmail emails the download link of a new statement
+func (s *EmailService) SendStatementEmail(ctx context.Context, to string, statement *models.ReportStatement, downloadURL string) error {
+	return s.send(ctx, emailTemplateStatement, to, map[string]interface{}{
+		"Month":         statement.PeriodStart.Format(statementMonthLayout),
+		"TotalValueUSD": statement.TotalValueUSD.StringFixed(2),
+		"DownloadURL":   downloadURL,
+		"LinkValidity":  fmt.Sprintf("%d days", int(statementLinkExpiry.Hours()/24)),
+	})
+}
+
+// SendPoolMigrationEmail emails a position holder the pool their pool migrated to
+func (s *EmailService) SendPoolMigrationEmail(ctx context.Context, to string, notice *models.YieldPoolMigrationNotice, successorURL string) error {
+	apy := ""
+	if notice.SuccessorAPY != nil {
+		apy = strconv.FormatFloat(*notice.SuccessorAPY, 'f', 2, 64)
+	}
+	return s.send(ctx, emailTemplateMigration, to, map[string]interface{}{
+		"PoolName":      notice.PoolName,
+		"Chain":         notice.Chain,
+		"SuccessorName": notice.SuccessorName,
+		"SuccessorAPY":  apy,
+		"SuccessorURL":  successorURL,
+	})
+}
+
+// SendBroadcastEmail emails an admin's broadcast to one of its recipients
+func (s *EmailService) SendBroadcastEmail(ctx context.Context, to string, broadcast *models.BroadcastEmail) error {
+	title := ""
+	if broadcast.Title != nil {
+		title = *broadcast.Title
+	}
+	return s.send(ctx, emailTemplateBroadcast, to, map[string]interface{}{
+		"Title":   title,
+		"Message": broadcast.Message,
+	})
+}
+
+// SendWebhookReportFailedEmail tells a webhook report's owner its deliveries keep failing
+func (s *EmailService) SendWebhookReportFailedEmail(ctx context.Context, to string, report *models.WebhookReport) error {
+	lastError := ""
+	if report.LastError != nil {
+		lastError = *report.LastError
+	}
+	return s.send(ctx, emailTemplateWebhookReportFailed, to, map[string]interface{}{
+		"Name":      report.Name,
+		"Failures":  report.ConsecutiveFailures,
+		"LastError": lastError,
+	})
+}
+
+// send renders the template and sends it, retrying failures that may pass. Suppressed
+// addresses are skipped without an error, since nothing the caller does will change that.
+func (s *EmailService) send(ctx context.Context, template, to string, data interface{}) error {
+	msg, err := email.Render(template, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	msg.Tag = template
+	delivery := &models.EmailDelivery{
+		Template: template,
+		To:       to,
+		Subject:  msg.Subject,
+		Provider: s.sender.Provider(),
+	}
+
+	suppressed, err := s.emailRepo.IsSuppressed(ctx, to)
+	if err != nil {
+		return err
+	}
+	if suppressed {
+		logger.Info("Email address suppressed, not sending", "to", to, "template", template)
+		delivery.Status = models.EmailStatusSuppressed
+		s.recordDelivery(ctx, delivery)
+		return nil
+	}
+
+	var messageID string
+	for {
+		delivery.Attempts++
+		messageID, err = s.sender.Send(ctx, msg)
+		if err == nil || email.IsPermanent(err) || delivery.Attempts > len(s.retryDelays) {
+			break
+		}
+		logger.Warn("Email send failed, retrying", "to", to, "template", template, "attempt", delivery.Attempts, "error", err)
+
+		timer := time.NewTimer(s.retryDelays[delivery.Attempts-1])
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		errMsg := err.Error()
+		delivery.Status, delivery.Error = models.EmailStatusFailed, &errMsg
+		s.recordDelivery(ctx, delivery)
+		return fmt.Errorf("failed to send %s email: %w", template, err)
+	}
+	delivery.Status = models.EmailStatusSent
+	if messageID != "" {
+		delivery.ProviderMessageID = &messageID
+	}
+	s.recordDelivery(ctx, delivery)
+	return nil
+}
+
+// recordDelivery logs rather than returns failures, so tracking never holds up email
+func (s *EmailService) recordDelivery(ctx context.Context, delivery *models.EmailDelivery) {
+	if err := s.emailRepo.RecordDelivery(ctx, delivery); err != nil {
+		logger.Warn("Failed to record email delivery", "to", delivery.To, "template", delivery.Template, "error", err)
+	}
+}
+
+// HandleEvents applies the delivery events a provider reported. Hard bounces and
+// complaints suppress the address and turn off email notifications for its users; soft
+// bounces are only logged, since the address may accept mail later.
+func (s *EmailService) HandleEvents(ctx context.Context, provider string, events []email.Event) error {
+	for _, event := range events {
+		var status, reason string
+		switch {
+		case event.Type == email.EventDelivery:
+			status = models.EmailStatusDelivered
+		case event.Type == email.EventBounce && event.Permanent:
+			status, reason = models.EmailStatusBounced, models.EmailSuppressionHardBounce
+		case event.Type == email.EventComplaint:
+			status, reason = models.EmailStatusComplained, models.EmailSuppressionComplaint
+		default:
+			logger.Info("Email soft bounce", "provider", provider, "to", event.Email, "detail", event.Detail)
+			continue
+		}
+
+		var detail *string
+		if event.Detail != "" {
+			detail = &event.Detail
+		}
+		if event.MessageID != "" {
+			if _, err := s.emailRepo.UpdateDeliveryStatus(ctx, provider, event.MessageID, status, detail); err != nil {
+				return errors.DatabaseError(err)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		added, err := s.emailRepo.Suppress(ctx, &models.EmailSuppression{Email: event.Email, Reason: reason, Detail: detail})
+		if err != nil {
+			return errors.DatabaseError(err)
+		}
+		disabled, err := s.emailRepo.DisableEmailNotifications(ctx, event.Email)
+		if err != nil {
+			return errors.DatabaseError(err)
+		}
+		if added || disabled > 0 {
+			logger.Info("Email address suppressed", "provider", provider, "to", event.Email, "reason", reason, "notificationsDisabled", disabled)
+		}
+	}
+	return nil
+}
+
+// ConfirmSNSSubscription visits the URL Amazon SNS sends to confirm a subscription of
+// the SES webhook to a topic
+func (s *EmailService) ConfirmSNSSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return errors.BadRequest("Invalid SNS subscribe URL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", subscribeURL, nil)
+	if err != nil {
+		return errors.BadRequest("Invalid SNS subscribe URL")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.ExternalServiceError("SNS", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.ExternalServiceError("SNS", fmt.Errorf("subscription confirmation returned status %d", resp.StatusCode))
+	}
+	logger.Info("Confirmed SNS subscription for email notifications", "host", u.Host)
+	return nil
+}
+
+// ListSuppressions returns the suppressed addresses, most recent first
+func (s *EmailService) ListSuppressions(ctx context.Context) ([]*models.EmailSuppression, error) {
+	suppressions, err := s.emailRepo.GetSuppressions(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return suppressions, nil
+}
+
+// AddSuppression stops email to an address
+func (s *EmailService) AddSuppression(ctx context.Context, req *models.CreateEmailSuppressionRequest) (*models.EmailSuppression, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil || addr.Name != "" {
+		return nil, errors.BadRequest("Invalid email address")
+	}
+
+	suppression := &models.EmailSuppression{Email: addr.Address, Reason: models.EmailSuppressionManual, Detail: req.Detail}
+	added, err := s.emailRepo.Suppress(ctx, suppression)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if !added {
+		return nil, errors.Conflict("Email address is already suppressed")
+	}
+	return suppression, nil
+}
+
+// RemoveSuppression lets email go to an address again. Notifications turned off when
+// it bounced stay off until its users turn them back on.
+func (s *EmailService) RemoveSuppression(ctx context.Context, address string) error {
+	removed, err := s.emailRepo.Unsuppress(ctx, address)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !removed {
+		return errors.NotFound("Email suppression")
+	}
+	return nil
+}
+
+// GetDeliveries lists the latest emails to an address
+func (s *EmailService) GetDeliveries(ctx context.Context, address string) ([]*models.EmailDelivery, error) {
+	if strings.TrimSpace(address) == "" {
+		return nil, errors.BadRequest("email is required")
+	}
+	deliveries, err := s.emailRepo.GetDeliveries(ctx, strings.TrimSpace(address), emailDeliveryLimit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return deliveries, nil
+}
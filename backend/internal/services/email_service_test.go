@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/email"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryEmailRepo struct {
+	repos.EmailRepository
+	deliveries   []*models.EmailDelivery
+	suppressions map[string]*models.EmailSuppression
+	statuses     map[string]string
+	disabled     []string
+}
+
+func newMemoryEmailRepo() *memoryEmailRepo {
+	return &memoryEmailRepo{suppressions: map[string]*models.EmailSuppression{}, statuses: map[string]string{}}
+}
+
+func (r *memoryEmailRepo) RecordDelivery(ctx context.Context, delivery *models.EmailDelivery) error {
+	delivery.ID = uuid.New()
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *memoryEmailRepo) UpdateDeliveryStatus(ctx context.Context, provider, messageID, status string, detail *string) (bool, error) {
+	r.statuses[provider+"/"+messageID] = status
+	return true, nil
+}
+
+func (r *memoryEmailRepo) IsSuppressed(ctx context.Context, address string) (bool, error) {
+	_, ok := r.suppressions[strings.ToLower(address)]
+	return ok, nil
+}
+
+func (r *memoryEmailRepo) Suppress(ctx context.Context, suppression *models.EmailSuppression) (bool, error) {
+	suppression.Email = strings.ToLower(suppression.Email)
+	if _, ok := r.suppressions[suppression.Email]; ok {
+		return false, nil
+	}
+	r.suppressions[suppression.Email] = suppression
+	return true, nil
+}
+
+func (r *memoryEmailRepo) DisableEmailNotifications(ctx context.Context, address string) (int64, error) {
+	r.disabled = append(r.disabled, address)
+	return 1, nil
+}
+
+// scriptedSender fails with the queued errors before succeeding
+type scriptedSender struct {
+	errs []error
+	sent []*email.Message
+}
+
+func (s *scriptedSender) Provider() string { return email.ProviderSES }
+
+func (s *scriptedSender) Send(ctx context.Context, msg *email.Message) (string, error) {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return "", err
+	}
+	s.sent = append(s.sent, msg)
+	return fmt.Sprintf("msg-%d", len(s.sent)), nil
+}
+
+func newTestEmailService(sender email.Sender, repo repos.EmailRepository) *EmailService {
+	service := NewEmailService(sender, repo)
+	service.retryDelays = []time.Duration{0, 0}
+	return service
+}
+
+func TestEmailServiceSend(t *testing.T) {
+	ctx := context.Background()
+	message := models.AlertNotificationMessage{
+		Type:           "price_above",
+		Target:         models.AlertTarget{Identifier: "ETH"},
+		TriggeredAt:    time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		TriggeredValue: map[string]interface{}{"price": 4000},
+	}
+
+	t.Run("sent", func(t *testing.T) {
+		repo := newMemoryEmailRepo()
+		sender := &scriptedSender{}
+		require.NoError(t, newTestEmailService(sender, repo).SendAlertEmail(ctx, "user@example.com", message))
+
+		require.Len(t, sender.sent, 1)
+		assert.Equal(t, "Alert triggered: price above on ETH", sender.sent[0].Subject)
+		assert.Contains(t, sender.sent[0].Text, "price: 4000")
+		require.Len(t, repo.deliveries, 1)
+		assert.Equal(t, models.EmailStatusSent, repo.deliveries[0].Status)
+		assert.Equal(t, "msg-1", *repo.deliveries[0].ProviderMessageID)
+		assert.Equal(t, 1, repo.deliveries[0].Attempts)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		repo := newMemoryEmailRepo()
+		sender := &scriptedSender{errs: []error{fmt.Errorf("throttled")}}
+		require.NoError(t, newTestEmailService(sender, repo).SendAlertEmail(ctx, "user@example.com", message))
+		assert.Len(t, sender.sent, 1)
+		assert.Equal(t, 2, repo.deliveries[0].Attempts)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		repo := newMemoryEmailRepo()
+		sender := &scriptedSender{errs: []error{fmt.Errorf("down"), fmt.Errorf("down"), fmt.Errorf("down")}}
+		require.Error(t, newTestEmailService(sender, repo).SendAlertEmail(ctx, "user@example.com", message))
+		assert.Equal(t, 3, repo.deliveries[0].Attempts)
+		assert.Equal(t, models.EmailStatusFailed, repo.deliveries[0].Status)
+	})
+
+	t.Run("permanent failure", func(t *testing.T) {
+		repo := newMemoryEmailRepo()
+		sender := &scriptedSender{errs: []error{&email.PermanentError{Err: fmt.Errorf("bad address")}}}
+		require.Error(t, newTestEmailService(sender, repo).SendAlertEmail(ctx, "user@example.com", message))
+		assert.Equal(t, 1, repo.deliveries[0].Attempts, "permanent failures aren't retried")
+	})
+
+	t.Run("suppressed", func(t *testing.T) {
+		repo := newMemoryEmailRepo()
+		repo.suppressions["user@example.com"] = &models.EmailSuppression{Email: "user@example.com"}
+		sender := &scriptedSender{}
+		require.NoError(t, newTestEmailService(sender, repo).SendAlertEmail(ctx, "User@Example.com", message))
+		assert.Empty(t, sender.sent)
+		assert.Equal(t, models.EmailStatusSuppressed, repo.deliveries[0].Status)
+	})
+}
+
+func TestEmailServiceHandleEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryEmailRepo()
+	service := newTestEmailService(&scriptedSender{}, repo)
+
+	err := service.HandleEvents(ctx, email.ProviderSES, []email.Event{
+		{Type: email.EventDelivery, Email: "ok@example.com", MessageID: "m1"},
+		{Type: email.EventBounce, Email: "busy@example.com", MessageID: "m2", Detail: "mailbox full"},
+		{Type: email.EventBounce, Email: "Gone@example.com", MessageID: "m3", Permanent: true, Detail: "user unknown"},
+		{Type: email.EventComplaint, Email: "annoyed@example.com", MessageID: "m4"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"ses/m1": models.EmailStatusDelivered,
+		"ses/m3": models.EmailStatusBounced,
+		"ses/m4": models.EmailStatusComplained,
+	}, repo.statuses, "soft bounces leave the delivery as it was")
+	require.Contains(t, repo.suppressions, "gone@example.com")
+	assert.Equal(t, models.EmailSuppressionHardBounce, repo.suppressions["gone@example.com"].Reason)
+	assert.Equal(t, models.EmailSuppressionComplaint, repo.suppressions["annoyed@example.com"].Reason)
+	assert.NotContains(t, repo.suppressions, "busy@example.com")
+	assert.Equal(t, []string{"Gone@example.com", "annoyed@example.com"}, repo.disabled)
+}
+
+func TestEmailServiceAddSuppression(t *testing.T) {
+	ctx := context.Background()
+	service := newTestEmailService(&scriptedSender{}, newMemoryEmailRepo())
+
+	_, err := service.AddSuppression(ctx, &models.CreateEmailSuppressionRequest{Email: "not an address"})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*errors.AppError).Status)
+
+	suppression, err := service.AddSuppression(ctx, &models.CreateEmailSuppressionRequest{Email: " Someone@Example.com "})
+	require.NoError(t, err)
+	assert.Equal(t, "someone@example.com", suppression.Email)
+	assert.Equal(t, models.EmailSuppressionManual, suppression.Reason)
+
+	_, err = service.AddSuppression(ctx, &models.CreateEmailSuppressionRequest{Email: "someone@example.com"})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, err.(*errors.AppError).Status)
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// escalationBatchSize caps how many due escalations one sweep advances
+const escalationBatchSize = 100
+
+type escalationStore interface {
+	Start(ctx context.Context, escalation *models.AlertEscalation) (bool, error)
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*models.AlertEscalation, error)
+	Advance(ctx context.Context, id uuid.UUID, step, nextStep int, nextStepAt time.Time, status string, lastError *string) (bool, error)
+}
+
+type escalationTeams interface {
+	GetPolicy(ctx context.Context, id uuid.UUID) (*models.EscalationPolicy, error)
+	GetRole(ctx context.Context, teamID, userID uuid.UUID) (string, error)
+	GetShifts(ctx context.Context, teamID uuid.UUID, from, to time.Time) ([]*models.OnCallShift, error)
+}
+
+type escalationAlerts interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Alert, error)
+}
+
+type escalationUsers interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+// EscalationService walks triggers of alerts with an escalation policy through the
+// policy's steps, one step each time the previous one's delay passes without a team
+// member acknowledging. Escalations page people, so they ignore quiet hours; a failed
+// step is recorded and the escalation moves on rather than stalling.
+type EscalationService struct {
+	escalations escalationStore
+	teams       escalationTeams
+	alerts      escalationAlerts
+	users       escalationUsers
+	channels    *NotificationChannelRegistry
+	templates   *NotificationTemplateService
+	clock       clock.Clock
+}
+
+func NewEscalationService(escalationRepo repos.EscalationRepository, teamRepo repos.TeamRepository, alertRepo repos.AlertRepository, userRepo repos.UserRepository, channels *NotificationChannelRegistry, templates *NotificationTemplateService) *EscalationService {
+	return &EscalationService{
+		escalations: escalationRepo,
+		teams:       teamRepo,
+		alerts:      alertRepo,
+		users:       userRepo,
+		channels:    channels,
+		templates:   templates,
+		clock:       clock.System,
+	}
+}
+
+// Start opens the escalation of a trigger of an alert with a policy and sends its first
+// step if it's due straight away. It reports false when the alert's policy is gone, so
+// the caller should notify the owner as usual. Starting a trigger twice is a no-op.
+func (s *EscalationService) Start(ctx context.Context, alert *models.Alert, history *models.AlertHistory) (bool, error) {
+	if alert.EscalationPolicyID == nil {
+		return false, nil
+	}
+	policy, err := s.teams.GetPolicy(ctx, *alert.EscalationPolicyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+	if policy == nil || len(policy.Steps) == 0 {
+		return false, nil
+	}
+
+	escalation := &models.AlertEscalation{
+		AlertID:     alert.ID,
+		HistoryID:   history.ID,
+		PolicyID:    policy.ID,
+		TeamID:      policy.TeamID,
+		NextStepAt:  history.TriggeredAt.Add(stepDelay(policy.Steps[0])),
+		TriggeredAt: history.TriggeredAt,
+	}
+	if _, err := s.escalations.Start(ctx, escalation); err != nil {
+		return false, err
+	}
+
+	if escalation.Status == models.EscalationStatusOpen && !escalation.NextStepAt.After(s.clock.Now()) {
+		if err := s.runStep(ctx, escalation, alert, policy); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// AdvanceDue sends the due steps of open escalations and returns how many were sent
+func (s *EscalationService) AdvanceDue(ctx context.Context) (int, error) {
+	due, err := s.escalations.GetDue(ctx, s.clock.Now(), escalationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	advanced := 0
+	for _, escalation := range due {
+		if ctx.Err() != nil {
+			return advanced, ctx.Err()
+		}
+
+		alert, err := s.alerts.GetByID(ctx, escalation.AlertID)
+		if err != nil {
+			logger.Warn("Failed to get escalated alert", "escalationID", escalation.ID, "alertID", escalation.AlertID, "error", err)
+			continue
+		}
+		policy, err := s.teams.GetPolicy(ctx, escalation.PolicyID)
+		if err != nil {
+			logger.Warn("Failed to get escalation policy", "escalationID", escalation.ID, "policyID", escalation.PolicyID, "error", err)
+			continue
+		}
+		if policy == nil {
+			// Policies cascade to their escalations, so this one is on its way out
+			continue
+		}
+
+		if err := s.runStep(ctx, escalation, alert, policy); err != nil {
+			logger.Warn("Failed to advance escalation", "escalationID", escalation.ID, "step", escalation.NextStep, "error", err)
+			continue
+		}
+		advanced++
+	}
+	return advanced, nil
+}
+
+// runStep sends the escalation's next step and schedules the one after it, or marks
+// the escalation exhausted when there's none left
+func (s *EscalationService) runStep(ctx context.Context, escalation *models.AlertEscalation, alert *models.Alert, policy *models.EscalationPolicy) error {
+	now := s.clock.Now()
+	step := escalation.NextStep
+
+	var lastError *string
+	if step < len(policy.Steps) {
+		if err := s.sendStep(ctx, escalation, alert, policy.TeamID, step, policy.Steps[step]); err != nil {
+			logger.Error("Failed to send escalation step", "escalationID", escalation.ID, "step", step, "channel", policy.Steps[step].Channel, "error", err)
+			msg := fmt.Sprintf("step %d (%s): %v", step+1, policy.Steps[step].Channel, err)
+			lastError = &msg
+		}
+	}
+
+	next, nextAt, status := step+1, now, models.EscalationStatusOpen
+	if next < len(policy.Steps) {
+		nextAt = now.Add(stepDelay(policy.Steps[next]))
+	} else {
+		status = models.EscalationStatusExhausted
+	}
+
+	advanced, err := s.escalations.Advance(ctx, escalation.ID, step, next, nextAt, status, lastError)
+	if err != nil {
+		return err
+	}
+	if advanced {
+		escalation.NextStep, escalation.NextStepAt, escalation.Status = next, nextAt, status
+		if lastError != nil {
+			escalation.LastError = lastError
+		}
+	}
+	return nil
+}
+
+// sendStep delivers one step of an escalation
+func (s *EscalationService) sendStep(ctx context.Context, escalation *models.AlertEscalation, alert *models.Alert, teamID uuid.UUID, index int, step models.EscalationStep) error {
+	channel, ok := s.channels.Get(step.Channel)
+	if !ok {
+		return fmt.Errorf("notification channel %s isn't available", step.Channel)
+	}
+
+	to, err := s.stepRecipient(ctx, alert, teamID, step, channel)
+	if err != nil {
+		return err
+	}
+
+	message := models.AlertNotificationMessage{
+		AlertID:        alert.ID,
+		HistoryID:      escalation.HistoryID,
+		DeliveryID:     uuid.NewSHA1(escalation.HistoryID, []byte(fmt.Sprintf("escalation:%d:%s", index, step.Channel))),
+		Type:           alert.Type,
+		Target:         alert.Target,
+		TriggeredAt:    escalation.TriggeredAt,
+		TriggeredValue: escalation.TriggeredValue,
+	}
+	if alert.Notification.Template != "" {
+		if s.templates == nil {
+			message.Text = renderNotificationTemplate(alert.Notification.Template, notificationVariables(message))
+		} else {
+			message.Text = s.templates.Render(ctx, alert.UserID, alert.Notification.Template, message)
+		}
+	} else {
+		message.Text = notificationText(message, channel.SupportsRichContent())
+	}
+	if index > 0 {
+		message.Text = fmt.Sprintf("[Escalated, step %d] %s", index+1, message.Text)
+	}
+
+	return channel.Send(ctx, to, message)
+}
+
+// stepRecipient resolves who a step goes to. Shared channels post to the alert's own
+// destination as its owner; member channels go to the named member or whoever is on
+// call, as long as they're still on the team.
+func (s *EscalationService) stepRecipient(ctx context.Context, alert *models.Alert, teamID uuid.UUID, step models.EscalationStep, channel NotificationChannel) (NotificationRecipient, error) {
+	if escalationSharedChannels[step.Channel] {
+		address := channel.Address(alert, nil)
+		if address == "" {
+			return NotificationRecipient{}, fmt.Errorf("alert has no %s destination", step.Channel)
+		}
+		return NotificationRecipient{UserID: alert.UserID, Address: address}, nil
+	}
+
+	memberID, err := s.stepMember(ctx, teamID, step)
+	if err != nil {
+		return NotificationRecipient{}, err
+	}
+
+	switch step.Channel {
+	case models.NotificationChannelInApp:
+		return NotificationRecipient{UserID: memberID, Address: memberID.String()}, nil
+	case models.NotificationChannelEmail:
+		user, err := s.users.GetByID(ctx, memberID)
+		if err != nil {
+			return NotificationRecipient{}, fmt.Errorf("failed to get team member: %w", err)
+		}
+		if user.Email == nil || *user.Email == "" {
+			return NotificationRecipient{}, fmt.Errorf("team member %s has no email address", memberID)
+		}
+		return NotificationRecipient{UserID: memberID, Address: *user.Email}, nil
+	default:
+		return NotificationRecipient{}, fmt.Errorf("unsupported escalation channel: %s", step.Channel)
+	}
+}
+
+// stepMember returns the team member a member step goes to
+func (s *EscalationService) stepMember(ctx context.Context, teamID uuid.UUID, step models.EscalationStep) (uuid.UUID, error) {
+	var memberID uuid.UUID
+	if step.OnCall {
+		now := s.clock.Now()
+		shifts, err := s.teams.GetShifts(ctx, teamID, now, now.Add(time.Second))
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to get on-call shifts: %w", err)
+		}
+		shift := currentOnCall(shifts, now)
+		if shift == nil {
+			return uuid.Nil, fmt.Errorf("nobody is on call")
+		}
+		memberID = shift.UserID
+	} else if step.UserID != nil {
+		memberID = *step.UserID
+	} else {
+		return uuid.Nil, fmt.Errorf("step has no recipient")
+	}
+
+	role, err := s.teams.GetRole(ctx, teamID, memberID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get team role: %w", err)
+	}
+	if role == "" {
+		return uuid.Nil, fmt.Errorf("user %s is no longer a team member", memberID)
+	}
+	return memberID, nil
+}
+
+func stepDelay(step models.EscalationStep) time.Duration {
+	return time.Duration(step.DelayMinutes) * time.Minute
+}
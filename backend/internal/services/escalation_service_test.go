@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type escalationStoreFake struct {
+	started  []*models.AlertEscalation
+	advances []escalationAdvance
+}
+
+type escalationAdvance struct {
+	step, nextStep int
+	nextStepAt     time.Time
+	status         string
+	lastError      *string
+}
+
+func (s *escalationStoreFake) Start(_ context.Context, e *models.AlertEscalation) (bool, error) {
+	e.ID = uuid.New()
+	e.Status = models.EscalationStatusOpen
+	s.started = append(s.started, e)
+	return true, nil
+}
+
+func (s *escalationStoreFake) GetDue(context.Context, time.Time, int) ([]*models.AlertEscalation, error) {
+	return nil, nil
+}
+
+func (s *escalationStoreFake) Advance(_ context.Context, _ uuid.UUID, step, nextStep int, nextStepAt time.Time, status string, lastError *string) (bool, error) {
+	s.advances = append(s.advances, escalationAdvance{step, nextStep, nextStepAt, status, lastError})
+	return true, nil
+}
+
+type escalationTeamsFake struct {
+	policy  *models.EscalationPolicy
+	members map[uuid.UUID]bool
+	shifts  []*models.OnCallShift
+}
+
+func (t *escalationTeamsFake) GetPolicy(context.Context, uuid.UUID) (*models.EscalationPolicy, error) {
+	return t.policy, nil
+}
+
+func (t *escalationTeamsFake) GetRole(_ context.Context, _, userID uuid.UUID) (string, error) {
+	if t.members[userID] {
+		return models.TeamRoleMember, nil
+	}
+	return "", nil
+}
+
+func (t *escalationTeamsFake) GetShifts(context.Context, uuid.UUID, time.Time, time.Time) ([]*models.OnCallShift, error) {
+	return t.shifts, nil
+}
+
+type escalationUsersFake map[uuid.UUID]*models.User
+
+func (u escalationUsersFake) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	return u[id], nil
+}
+
+// recordingChannel records what it's asked to send
+type recordingChannel struct {
+	name string
+	sent []NotificationRecipient
+	text []string
+}
+
+func (c *recordingChannel) Name() string { return c.name }
+
+func (c *recordingChannel) Address(alert *models.Alert, _ *models.User) string {
+	if c.name == models.NotificationChannelDiscord {
+		return alert.Notification.Discord
+	}
+	return ""
+}
+
+func (c *recordingChannel) Send(_ context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error {
+	c.sent = append(c.sent, to)
+	c.text = append(c.text, message.Text)
+	return nil
+}
+
+func (c *recordingChannel) SupportsRichContent() bool { return false }
+
+func TestEscalationService_DiscordThenEmailOnCall(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	owner, second := uuid.New(), uuid.New()
+	email := "second@example.com"
+
+	discord := &recordingChannel{name: models.NotificationChannelDiscord}
+	mail := &recordingChannel{name: models.NotificationChannelEmail}
+	channels := NewNotificationChannelRegistry()
+	require.NoError(t, channels.Register(discord))
+	require.NoError(t, channels.Register(mail))
+
+	policy := &models.EscalationPolicy{
+		ID:     uuid.New(),
+		TeamID: uuid.New(),
+		Steps: []models.EscalationStep{
+			{Channel: models.NotificationChannelDiscord},
+			{DelayMinutes: 10, Channel: models.NotificationChannelEmail, OnCall: true},
+		},
+	}
+	store := &escalationStoreFake{}
+	fake := clock.NewFake(now)
+	s := &EscalationService{
+		escalations: store,
+		teams: &escalationTeamsFake{
+			policy:  policy,
+			members: map[uuid.UUID]bool{second: true},
+			shifts:  []*models.OnCallShift{{UserID: second, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}},
+		},
+		users:    escalationUsersFake{second: {ID: second, Email: &email}},
+		channels: channels,
+		clock:    fake,
+	}
+
+	alert := &models.Alert{
+		ID:                 uuid.New(),
+		UserID:             owner,
+		Type:               "price_above",
+		Notification:       models.AlertNotification{Discord: "https://discord.com/api/webhooks/1/abc"},
+		EscalationPolicyID: &policy.ID,
+	}
+	history := &models.AlertHistory{ID: uuid.New(), TriggeredAt: now}
+
+	escalated, err := s.Start(context.Background(), alert, history)
+	require.NoError(t, err)
+	assert.True(t, escalated)
+
+	// The first step goes out straight away to the alert's Discord webhook
+	require.Len(t, discord.sent, 1)
+	assert.Equal(t, NotificationRecipient{UserID: owner, Address: alert.Notification.Discord}, discord.sent[0])
+	assert.Empty(t, mail.sent)
+	require.Len(t, store.advances, 1)
+	assert.Equal(t, escalationAdvance{0, 1, now.Add(10 * time.Minute), models.EscalationStatusOpen, nil}, store.advances[0])
+
+	// Ten minutes later nobody has acknowledged, so whoever is on call gets an email
+	fake.Advance(10 * time.Minute)
+	escalation := store.started[0]
+	require.NoError(t, s.runStep(context.Background(), escalation, alert, policy))
+
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, NotificationRecipient{UserID: second, Address: email}, mail.sent[0])
+	assert.Contains(t, mail.text[0], "[Escalated, step 2]")
+	require.Len(t, store.advances, 2)
+	assert.Equal(t, models.EscalationStatusExhausted, store.advances[1].status)
+	assert.Nil(t, store.advances[1].lastError)
+}
+
+func TestEscalationService_FailedStepStillAdvances(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	channels := NewNotificationChannelRegistry()
+	require.NoError(t, channels.Register(&recordingChannel{name: models.NotificationChannelEmail}))
+
+	policy := &models.EscalationPolicy{
+		ID:     uuid.New(),
+		TeamID: uuid.New(),
+		Steps: []models.EscalationStep{
+			{Channel: models.NotificationChannelEmail, OnCall: true},
+			{DelayMinutes: 5, Channel: models.NotificationChannelEmail, OnCall: true},
+		},
+	}
+	store := &escalationStoreFake{}
+	s := &EscalationService{
+		escalations: store,
+		teams:       &escalationTeamsFake{policy: policy},
+		channels:    channels,
+		clock:       clock.NewFake(now),
+	}
+
+	escalation := &models.AlertEscalation{ID: uuid.New(), Status: models.EscalationStatusOpen}
+	require.NoError(t, s.runStep(context.Background(), escalation, &models.Alert{ID: uuid.New()}, policy))
+
+	require.Len(t, store.advances, 1)
+	advance := store.advances[0]
+	assert.Equal(t, 1, advance.nextStep)
+	assert.Equal(t, models.EscalationStatusOpen, advance.status)
+	require.NotNil(t, advance.lastError)
+	assert.Contains(t, *advance.lastError, "nobody is on call")
+}
+
+func TestEscalationService_StartWithoutPolicy(t *testing.T) {
+	s := &EscalationService{teams: &escalationTeamsFake{}, escalations: &escalationStoreFake{}, clock: clock.System}
+	policyID := uuid.New()
+
+	escalated, err := s.Start(context.Background(), &models.Alert{EscalationPolicyID: &policyID}, &models.AlertHistory{})
+	require.NoError(t, err)
+	assert.False(t, escalated, "a deleted policy falls back to notifying the owner")
+}
+
+func TestValidateEscalationSteps(t *testing.T) {
+	member := uuid.New()
+	tests := []struct {
+		name  string
+		steps []models.EscalationStep
+		valid bool
+	}{
+		{"discord then member email", []models.EscalationStep{
+			{Channel: "discord"},
+			{DelayMinutes: 10, Channel: "email", UserID: &member},
+		}, true},
+		{"on-call in-app", []models.EscalationStep{{Channel: "in_app", OnCall: true}}, true},
+		{"no steps", nil, false},
+		{"negative delay", []models.EscalationStep{{DelayMinutes: -1, Channel: "discord"}}, false},
+		{"delay over a day", []models.EscalationStep{{DelayMinutes: 24*60 + 1, Channel: "discord"}}, false},
+		{"shared channel with recipient", []models.EscalationStep{{Channel: "discord", UserID: &member}}, false},
+		{"member channel without recipient", []models.EscalationStep{{Channel: "email"}}, false},
+		{"member channel with both recipients", []models.EscalationStep{{Channel: "email", UserID: &member, OnCall: true}}, false},
+		{"unknown channel", []models.EscalationStep{{Channel: "sms", OnCall: true}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEscalationSteps(tt.steps)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCurrentOnCall(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rotation := &models.OnCallShift{ID: uuid.New(), StartsAt: now.Add(-12 * time.Hour), EndsAt: now.Add(12 * time.Hour)}
+	override := &models.OnCallShift{ID: uuid.New(), StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	ended := &models.OnCallShift{ID: uuid.New(), StartsAt: now.Add(-2 * time.Hour), EndsAt: now}
+
+	assert.Equal(t, override, currentOnCall([]*models.OnCallShift{rotation, override, ended}, now))
+	assert.Equal(t, rotation, currentOnCall([]*models.OnCallShift{rotation, ended}, now))
+	assert.Nil(t, currentOnCall([]*models.OnCallShift{ended}, now), "shifts end exclusively")
+	assert.Nil(t, currentOnCall(nil, now))
+}
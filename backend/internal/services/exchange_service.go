@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+)
+
+type ExchangeService struct {
+	exchangeRepo repos.ExchangeRepository
+	encryptor    *crypto.Encryptor
+}
+
+// NewExchangeService creates the exchange account service; a nil encryptor disables
+// connecting accounts, as their API keys can't be stored
+func NewExchangeService(exchangeRepo repos.ExchangeRepository, encryptor *crypto.Encryptor) *ExchangeService {
+	return &ExchangeService{
+		exchangeRepo: exchangeRepo,
+		encryptor:    encryptor,
+	}
+}
+
+// GetAccounts returns the user's connected exchange accounts
+func (s *ExchangeService) GetAccounts(ctx context.Context, userID uuid.UUID) ([]*models.ExchangeAccount, error) {
+	accounts, err := s.exchangeRepo.GetAccounts(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get exchange accounts", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch exchange accounts")
+	}
+	if accounts == nil {
+		accounts = []*models.ExchangeAccount{}
+	}
+	return accounts, nil
+}
+
+// AddAccount connects an exchange account. The key is checked by reading the account's
+// balances, which are stored right away; trade history follows with the next sync.
+func (s *ExchangeService) AddAccount(ctx context.Context, userID uuid.UUID, req *models.AddExchangeAccountRequest) (*models.ExchangeAccount, error) {
+	if s.encryptor == nil {
+		return nil, errors.BadRequest("API key storage is not configured")
+	}
+	exchange := strings.ToLower(strings.TrimSpace(req.Exchange))
+	if !external.IsSupportedExchange(exchange) {
+		return nil, errors.BadRequest("Invalid exchange. Must be one of: binance, coinbase, kraken")
+	}
+
+	creds := external.ExchangeCredentials{
+		APIKey:    strings.TrimSpace(req.APIKey),
+		APISecret: strings.TrimSpace(req.APISecret),
+	}
+	if len(creds.APIKey) < 8 {
+		return nil, errors.BadRequest("api_key is too short")
+	}
+	connector, err := external.NewExchangeConnector(exchange, creds)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("Invalid API credentials: %v", err))
+	}
+
+	balances, err := connector.GetBalances(ctx)
+	if err != nil {
+		logger.Warn("Failed to read exchange account with new API key", "error", err, "userID", userID, "exchange", exchange)
+		return nil, errors.BadRequest("Failed to read the exchange account with the given API key")
+	}
+
+	sealed, err := s.sealCredentials(creds)
+	if err != nil {
+		logger.Error("Failed to encrypt exchange credentials", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to store exchange account")
+	}
+
+	account := &models.ExchangeAccount{
+		UserID:               userID,
+		Exchange:             exchange,
+		Label:                req.Label,
+		KeyHint:              creds.APIKey[len(creds.APIKey)-4:],
+		EncryptedCredentials: sealed,
+	}
+	if err := s.exchangeRepo.AddAccount(ctx, account); err != nil {
+		logger.Error("Failed to add exchange account", "error", err, "userID", userID, "exchange", exchange)
+		return nil, errors.Internal("Failed to store exchange account")
+	}
+	if err := s.exchangeRepo.ReplaceBalances(ctx, account.ID, ExchangeAssetBalances(balances)); err != nil {
+		logger.Error("Failed to store exchange balances", "error", err, "accountID", account.ID)
+	}
+
+	return account, nil
+}
+
+// RemoveAccount disconnects an exchange account along with its synced data
+func (s *ExchangeService) RemoveAccount(ctx context.Context, userID, accountID uuid.UUID) error {
+	deleted, err := s.exchangeRepo.DeleteAccount(ctx, userID, accountID)
+	if err != nil {
+		logger.Error("Failed to remove exchange account", "error", err, "userID", userID, "accountID", accountID)
+		return errors.Internal("Failed to remove exchange account")
+	}
+	if !deleted {
+		return errors.NotFound("Exchange account")
+	}
+	return nil
+}
+
+// Connector opens an account's stored credentials and returns its exchange connector
+func (s *ExchangeService) Connector(account *models.ExchangeAccount) (external.ExchangeConnector, error) {
+	if s.encryptor == nil {
+		return nil, fmt.Errorf("encryption is not configured")
+	}
+
+	plain, err := s.encryptor.Decrypt(account.EncryptedCredentials)
+	if err != nil {
+		return nil, err
+	}
+	var creds external.ExchangeCredentials
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return nil, fmt.Errorf("failed to decode exchange credentials: %w", err)
+	}
+
+	return external.NewExchangeConnector(account.Exchange, creds)
+}
+
+// GetPortfolio returns the user's exchange accounts with their balances as last synced,
+// valued at current prices. Assets without a price count as zero.
+func (s *ExchangeService) GetPortfolio(ctx context.Context, userID uuid.UUID, coinGeckoAPIKey string) (*models.ExchangePortfolio, error) {
+	accounts, err := s.GetAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.exchangeRepo.GetBalances(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get exchange balances", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch exchange balances")
+	}
+
+	symbols := make([]string, 0, len(balances))
+	for _, balance := range balances {
+		symbols = append(symbols, balance.Asset)
+	}
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys("", coinGeckoAPIKey)
+	prices, err := blockchainService.GetSymbolPricesUSD(ctx, symbols)
+	if err != nil {
+		logger.Warn("Failed to get exchange asset prices, balances are reported unvalued", "error", err)
+		prices = map[string]float64{}
+	}
+
+	portfolio := &models.ExchangePortfolio{Accounts: make([]*models.ExchangeAccountHoldings, 0, len(accounts))}
+	byAccount := make(map[uuid.UUID]*models.ExchangeAccountHoldings, len(accounts))
+	for _, account := range accounts {
+		holdings := &models.ExchangeAccountHoldings{Account: account, Balances: []*models.ExchangeAssetBalance{}}
+		byAccount[account.ID] = holdings
+		portfolio.Accounts = append(portfolio.Accounts, holdings)
+	}
+
+	for _, balance := range balances {
+		holdings, ok := byAccount[balance.AccountID]
+		if !ok {
+			continue
+		}
+		ref := models.ExchangeAsset(holdings.Account.Exchange, balance.Asset).ID
+		balance.AssetRef = &ref
+
+		price, ok := prices[balance.Asset]
+		if !ok && external.IsUSDAsset(balance.Asset) {
+			price, ok = 1, true
+		}
+		if ok {
+			value := (balance.Free + balance.Locked) * price
+			balance.PriceUSD, balance.ValueUSD = &price, &value
+			holdings.ValueUSD += value
+			portfolio.TotalValueUSD += value
+		}
+		holdings.Balances = append(holdings.Balances, balance)
+	}
+
+	return portfolio, nil
+}
+
+// GetTrades returns a page of the user's synced exchange trades, newest first
+func (s *ExchangeService) GetTrades(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ExchangeTrade, error) {
+	trades, err := s.exchangeRepo.GetTrades(ctx, userID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get exchange trades", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch exchange trades")
+	}
+	if trades == nil {
+		trades = []*models.ExchangeTrade{}
+	}
+	return trades, nil
+}
+
+// CalculatePnL computes realized and unrealized PnL of one asset over the user's synced
+// exchange trades
+func (s *ExchangeService) CalculatePnL(ctx context.Context, userID uuid.UUID, asset string, from, to time.Time, method pnl.CalculationMethod, coinGeckoAPIKey string) (*models.PnLCalculation, error) {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if asset == "" {
+		return nil, errors.BadRequest("Asset is required")
+	}
+
+	trades, err := s.exchangeRepo.GetAssetTradesBetween(ctx, userID, asset, from, to)
+	if err != nil {
+		logger.Error("Failed to get exchange trades", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch exchange trades")
+	}
+
+	lots := pnl.ExchangeLots(trades, asset)
+	if len(lots) == 0 {
+		return nil, errors.NotFound("Exchange trades")
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys("", coinGeckoAPIKey)
+	prices, err := blockchainService.GetSymbolPricesUSD(ctx, []string{asset})
+	if err != nil {
+		logger.Warn("Failed to get asset price", "error", err, "asset", asset)
+	}
+	price, ok := prices[asset]
+	if !ok {
+		if !external.IsUSDAsset(asset) {
+			return nil, errors.Internal(fmt.Sprintf("Failed to fetch %s price", asset))
+		}
+		price = 1
+	}
+
+	calculation, err := pnl.NewCalculator(method).CalculatePnL(lots, fmt.Sprintf("%.10f", price))
+	if err != nil {
+		logger.Error("Failed to calculate exchange PnL", "error", err, "userID", userID, "asset", asset)
+		return nil, errors.Internal("Failed to calculate PnL")
+	}
+	calculation.TokenSymbol = asset
+
+	return calculation, nil
+}
+
+func (s *ExchangeService) sealCredentials(creds external.ExchangeCredentials) ([]byte, error) {
+	plain, err := json.Marshal(creds)
+	if err != nil {
+		return nil, err
+	}
+	return s.encryptor.Encrypt(plain)
+}
+
+// ExchangeAssetBalances converts connector balances for storage
+func ExchangeAssetBalances(balances []external.ExchangeBalance) []*models.ExchangeAssetBalance {
+	result := make([]*models.ExchangeAssetBalance, len(balances))
+	for i, balance := range balances {
+		result[i] = &models.ExchangeAssetBalance{
+			Asset:  balance.Asset,
+			Free:   balance.Free,
+			Locked: balance.Locked,
+		}
+	}
+	return result
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// maxTrackedExposures bounds how many exposures a process remembers having tracked;
+// past it they're forgotten, which at worst tracks an exposure again
+const maxTrackedExposures = 100000
+
+// experimentVariant is a variant of an experiment with its share of assignments
+type experimentVariant struct {
+	name   string
+	weight int
+}
+
+// ExperimentService runs A/B experiments on top of feature flags. An experiment is a
+// flag whose value has "variants", a map of variant names to integer weights, e.g.
+// {"enabled": true, "variants": {"control": 50, "strict_presets": 50}}. Users are
+// assigned by a stable hash of the experiment and their ID, so they keep their variant
+// as long as the weights don't change; while the flag is off everyone gets the control.
+//
+// Exposures go through the analytics pipeline, so they reach the sink and honor opt-outs,
+// and are recorded here with the usage events exposed users go on to, from which the
+// results count conversions per variant.
+type ExperimentService struct {
+	repo            repos.ExperimentRepository
+	featureFlagRepo repos.FeatureFlagRepository
+	analytics       EventTracker
+
+	mu sync.Mutex
+	// exposed remembers exposures already tracked by this process, so repeated reads of
+	// a variant don't flood the sink
+	exposed map[string]bool
+}
+
+// NewExperimentService tracks exposures through analytics, which may be nil
+func NewExperimentService(repo repos.ExperimentRepository, featureFlagRepo repos.FeatureFlagRepository, analytics EventTracker) *ExperimentService {
+	return &ExperimentService{
+		repo:            repo,
+		featureFlagRepo: featureFlagRepo,
+		analytics:       analytics,
+		exposed:         make(map[string]bool),
+	}
+}
+
+// Variant returns the user's variant of the experiment, tracking the exposure while the
+// experiment is on
+func (s *ExperimentService) Variant(ctx context.Context, userID uuid.UUID, experiment string) (*models.ExperimentAssignment, error) {
+	active, variants, err := s.experiment(ctx, experiment)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &models.ExperimentAssignment{Experiment: experiment, Variant: models.ExperimentControl}
+	if !active {
+		return assignment, nil
+	}
+	assignment.Variant, assignment.Active = assignVariant(experiment, userID, variants), true
+	s.expose(userID, assignment)
+	return assignment, nil
+}
+
+// Results returns each variant's exposures and the share of them that converted on
+// each tracked action
+func (s *ExperimentService) Results(ctx context.Context, experiment string) (*models.ExperimentResults, error) {
+	active, variants, err := s.experiment(ctx, experiment)
+	if err != nil {
+		return nil, err
+	}
+	counted, err := s.repo.GetResults(ctx, experiment)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	byVariant := make(map[string]models.ExperimentVariantResult, len(counted))
+	for _, result := range counted {
+		byVariant[result.Variant] = result
+	}
+	results := &models.ExperimentResults{Experiment: experiment, Active: active, Variants: []models.ExperimentVariantResult{}}
+	// Configured variants come first, then ones since removed that still have exposures
+	for _, variant := range variants {
+		result, ok := byVariant[variant.name]
+		if !ok {
+			result = models.ExperimentVariantResult{Variant: variant.name, Conversions: []models.ExperimentConversion{}}
+		}
+		result.Weight = variant.weight
+		results.Variants = append(results.Variants, result)
+		delete(byVariant, variant.name)
+	}
+	for _, result := range counted {
+		if _, ok := byVariant[result.Variant]; ok {
+			results.Variants = append(results.Variants, result)
+		}
+	}
+	for i := range results.Variants {
+		result := &results.Variants[i]
+		for j := range result.Conversions {
+			if result.Exposures > 0 {
+				rate := float64(result.Conversions[j].Users) / float64(result.Exposures)
+				result.Conversions[j].Rate = math.Round(rate*10000) / 10000
+			}
+		}
+	}
+	return results, nil
+}
+
+// recordEvents keeps exposures and, for users exposed to experiments, the actions that
+// count as conversions. It's given the events sent to the analytics sink.
+func (s *ExperimentService) recordEvents(ctx context.Context, events []trackedEvent) error {
+	for _, e := range events {
+		var err error
+		if e.event.Name == models.AnalyticsExperimentExposure {
+			experiment, _ := e.event.Properties["experiment"].(string)
+			variant, _ := e.event.Properties["variant"].(string)
+			err = s.repo.RecordExposure(ctx, &models.ExperimentExposure{
+				Experiment: experiment,
+				UserID:     e.userID,
+				Variant:    variant,
+				ExposedAt:  e.event.Timestamp,
+			})
+		} else {
+			err = s.repo.RecordConversion(ctx, e.userID, e.event.Name, e.event.Timestamp)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expose tracks the user's exposure to their variant, once per process
+func (s *ExperimentService) expose(userID uuid.UUID, assignment *models.ExperimentAssignment) {
+	if s.analytics == nil {
+		return
+	}
+	key := assignment.Experiment + "/" + userID.String()
+	s.mu.Lock()
+	if len(s.exposed) >= maxTrackedExposures {
+		s.exposed = make(map[string]bool)
+	}
+	seen := s.exposed[key]
+	s.exposed[key] = true
+	s.mu.Unlock()
+	if seen {
+		return
+	}
+	s.analytics.Track(userID, models.AnalyticsExperimentExposure, map[string]interface{}{
+		"experiment": assignment.Experiment,
+		"variant":    assignment.Variant,
+	})
+}
+
+// experiment reads the experiment's flag, returning whether it's on and its variants in
+// name order
+func (s *ExperimentService) experiment(ctx context.Context, name string) (bool, []experimentVariant, error) {
+	flag, err := s.featureFlagRepo.GetByName(ctx, name)
+	if err != nil || flag == nil {
+		return false, nil, errors.NotFound("Experiment")
+	}
+	raw, ok := flag.Value["variants"].(map[string]interface{})
+	if !ok {
+		return false, nil, errors.NotFound("Experiment")
+	}
+	variants, err := parseExperimentVariants(raw)
+	if err != nil {
+		logger.Warn("Invalid experiment variants, serving the control", "experiment", name, "error", err)
+		return false, nil, nil
+	}
+	enabled, _ := flag.Value["enabled"].(bool)
+	return enabled, variants, nil
+}
+
+func parseExperimentVariants(raw map[string]interface{}) ([]experimentVariant, error) {
+	variants := make([]experimentVariant, 0, len(raw))
+	for name, value := range raw {
+		weight, ok := value.(float64)
+		if !ok || weight < 0 || weight != math.Trunc(weight) {
+			return nil, fmt.Errorf("variant %q needs a whole, non-negative weight", name)
+		}
+		variants = append(variants, experimentVariant{name: name, weight: int(weight)})
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].name < variants[j].name })
+
+	total := 0
+	for _, variant := range variants {
+		total += variant.weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no variant has any weight")
+	}
+	return variants, nil
+}
+
+// assignVariant picks the user's variant by hashing the experiment and user onto the
+// variants' weights. The experiment is part of the hash so a user's variants in
+// different experiments are independent.
+func assignVariant(experiment string, userID uuid.UUID, variants []experimentVariant) string {
+	total := 0
+	for _, variant := range variants {
+		total += variant.weight
+	}
+	sum := sha256.Sum256([]byte(experiment + "/" + userID.String()))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for _, variant := range variants {
+		if bucket < variant.weight {
+			return variant.name
+		}
+		bucket -= variant.weight
+	}
+	return variants[len(variants)-1].name
+}
\ No newline at end of file
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryExperimentRepo struct {
+	exposures   map[string]*models.ExperimentExposure
+	conversions map[string]time.Time
+}
+
+func newMemoryExperimentRepo() *memoryExperimentRepo {
+	return &memoryExperimentRepo{exposures: map[string]*models.ExperimentExposure{}, conversions: map[string]time.Time{}}
+}
+
+func (r *memoryExperimentRepo) RecordExposure(_ context.Context, exposure *models.ExperimentExposure) error {
+	key := exposure.Experiment + "/" + exposure.UserID.String()
+	if _, ok := r.exposures[key]; !ok {
+		r.exposures[key] = exposure
+	}
+	return nil
+}
+
+func (r *memoryExperimentRepo) RecordConversion(_ context.Context, userID uuid.UUID, metric string, at time.Time) error {
+	for _, exposure := range r.exposures {
+		key := exposure.Experiment + "/" + userID.String() + "/" + metric
+		if _, ok := r.conversions[key]; !ok && exposure.UserID == userID && !exposure.ExposedAt.After(at) {
+			r.conversions[key] = at
+		}
+	}
+	return nil
+}
+
+func (r *memoryExperimentRepo) GetResults(_ context.Context, experiment string) ([]models.ExperimentVariantResult, error) {
+	counts := map[string]*models.ExperimentVariantResult{}
+	var order []string
+	for _, exposure := range r.exposures {
+		if exposure.Experiment != experiment {
+			continue
+		}
+		result, ok := counts[exposure.Variant]
+		if !ok {
+			result = &models.ExperimentVariantResult{Variant: exposure.Variant, Conversions: []models.ExperimentConversion{}}
+			counts[exposure.Variant] = result
+			order = append(order, exposure.Variant)
+		}
+		result.Exposures++
+		if _, ok := r.conversions[experiment+"/"+exposure.UserID.String()+"/"+models.AnalyticsAlertTriggered]; ok {
+			if len(result.Conversions) == 0 {
+				result.Conversions = append(result.Conversions, models.ExperimentConversion{Metric: models.AnalyticsAlertTriggered})
+			}
+			result.Conversions[0].Users++
+		}
+	}
+	results := []models.ExperimentVariantResult{}
+	for _, variant := range order {
+		results = append(results, *counts[variant])
+	}
+	return results, nil
+}
+
+func experimentFlags(enabled bool, variants map[string]interface{}) *memoryFeatureFlagRepo {
+	return &memoryFeatureFlagRepo{flags: map[string]*models.FeatureFlag{
+		"alert_presets": {Name: "alert_presets", Value: map[string]interface{}{"enabled": enabled, "variants": variants}},
+		"billing":       {Name: "billing", Value: map[string]interface{}{"enabled": true}},
+	}}
+}
+
+func TestAssignVariant(t *testing.T) {
+	variants := []experimentVariant{{name: "control", weight: 50}, {name: "strict", weight: 50}}
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		userID := uuid.New()
+		variant := assignVariant("alert_presets", userID, variants)
+		assert.Equal(t, variant, assignVariant("alert_presets", userID, variants), "assignment is stable")
+		counts[variant]++
+	}
+	assert.InDelta(t, 1000, counts["control"], 150)
+	assert.InDelta(t, 1000, counts["strict"], 150)
+
+	userID := uuid.New()
+	assert.Equal(t, "strict", assignVariant("alert_presets", userID, []experimentVariant{{name: "control"}, {name: "strict", weight: 1}}),
+		"variants without weight get nobody")
+}
+
+func TestExperimentVariant(t *testing.T) {
+	ctx := context.Background()
+	analytics := NewAnalyticsService(&memoryAnalyticsRepo{optOuts: map[uuid.UUID]time.Time{}}, nil, "salt")
+	service := NewExperimentService(newMemoryExperimentRepo(), experimentFlags(true, map[string]interface{}{"control": 1.0, "strict": 1.0}), analytics)
+	analytics.SetExperiments(service)
+	userID := uuid.New()
+
+	assignment, err := service.Variant(ctx, userID, "alert_presets")
+	require.NoError(t, err)
+	assert.True(t, assignment.Active)
+	assert.Contains(t, []string{"control", "strict"}, assignment.Variant)
+	again, err := service.Variant(ctx, userID, "alert_presets")
+	require.NoError(t, err)
+	assert.Equal(t, assignment.Variant, again.Variant)
+	assert.Len(t, analytics.events, 1, "an exposure is tracked once")
+
+	_, err = service.Variant(ctx, userID, "billing")
+	assert.Error(t, err, "flags without variants aren't experiments")
+	_, err = service.Variant(ctx, userID, "missing")
+	assert.Error(t, err)
+
+	off := NewExperimentService(newMemoryExperimentRepo(), experimentFlags(false, map[string]interface{}{"control": 1.0, "strict": 1.0}), analytics)
+	assignment, err = off.Variant(ctx, userID, "alert_presets")
+	require.NoError(t, err)
+	assert.False(t, assignment.Active)
+	assert.Equal(t, models.ExperimentControl, assignment.Variant)
+
+	invalid := NewExperimentService(newMemoryExperimentRepo(), experimentFlags(true, map[string]interface{}{"strict": "half"}), analytics)
+	assignment, err = invalid.Variant(ctx, userID, "alert_presets")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExperimentControl, assignment.Variant, "a misconfigured experiment serves the control")
+}
+
+// tickingClock moves a second forward on every read, so each tracked event
+// gets a distinct timestamp
+type tickingClock struct{ now time.Time }
+
+func (c *tickingClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func TestExperimentResults(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemoryExperimentRepo()
+	analytics := NewAnalyticsService(&memoryAnalyticsRepo{optOuts: map[uuid.UUID]time.Time{}}, nil, "salt")
+	service := NewExperimentService(repo, experimentFlags(true, map[string]interface{}{"control": 0.0, "strict": 1.0}), analytics)
+	analytics.SetExperiments(service)
+	analytics.clock = &tickingClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	converted, exposed, late := uuid.New(), uuid.New(), uuid.New()
+	analytics.Track(late, models.AnalyticsAlertTriggered, nil)
+	for _, userID := range []uuid.UUID{converted, exposed, late} {
+		_, err := service.Variant(ctx, userID, "alert_presets")
+		require.NoError(t, err)
+	}
+	analytics.Track(converted, models.AnalyticsAlertTriggered, nil)
+	stopped, stop := context.WithCancel(ctx)
+	stop()
+	analytics.Run(stopped)
+
+	results, err := service.Results(ctx, "alert_presets")
+	require.NoError(t, err)
+	assert.True(t, results.Active)
+	require.Len(t, results.Variants, 2)
+	assert.Equal(t, "control", results.Variants[0].Variant)
+	assert.Zero(t, results.Variants[0].Exposures)
+	strict := results.Variants[1]
+	assert.Equal(t, 1, strict.Weight)
+	assert.Equal(t, 3, strict.Exposures)
+	require.Len(t, strict.Conversions, 1, "actions before exposure don't convert")
+	assert.Equal(t, 1, strict.Conversions[0].Users)
+	assert.Equal(t, 0.3333, strict.Conversions[0].Rate)
+}
\ No newline at end of file
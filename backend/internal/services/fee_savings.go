@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultFeeSavingsDays is how far back gas spend is looked at unless asked otherwise
+	defaultFeeSavingsDays = 90
+	// maxFeeSavingsDays is the longest period gas spend is looked at over
+	maxFeeSavingsDays = 365
+	// minFeeSavingsUSD is the least an L2 has to have saved to be suggested
+	minFeeSavingsUSD = 5
+	// defaultFeeSavingsBridgeAmount is the ETH, in wei, the suggested bridge route moves
+	// unless asked otherwise
+	defaultFeeSavingsBridgeAmount = "100000000000000000"
+)
+
+// feeSavingsL1Chains are the chains whose gas spend is compared against L2s
+var feeSavingsL1Chains = map[int]bool{blockchain.ChainIDEthereum: true}
+
+// feeSavingsL2Chains are the rollups activity is suggested to move to
+var feeSavingsL2Chains = []int{blockchain.ChainIDArbitrum, blockchain.ChainIDOptimism}
+
+// FeeSavingsSuggestion is what one type of a wallet's L1 activity would have cost on the
+// cheapest L2, with a route to bridge ETH there
+type FeeSavingsSuggestion struct {
+	FromChain int     `json:"fromChain"`
+	ToChain   int     `json:"toChain"`
+	Type      string  `json:"type"`
+	TxCount   int     `json:"txCount"`
+	SpentUSD  float64 `json:"spentUsd"`
+	// EstimatedUSD is the same gas at the L2's current gas and ETH prices. It leaves out
+	// the L1 data fee rollups charge on top, so it's a lower bound.
+	EstimatedUSD float64      `json:"estimatedUsd"`
+	SavingsUSD   float64      `json:"savingsUsd"`
+	Message      string       `json:"message"`
+	Route        *BridgeRoute `json:"route,omitempty"`
+}
+
+type FeeSavingsReport struct {
+	Address     string                 `json:"address"`
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to"`
+	SpentUSD    float64                `json:"spentUsd"`
+	SavingsUSD  float64                `json:"savingsUsd"`
+	Suggestions []FeeSavingsSuggestion `json:"suggestions"`
+	// Warnings lists L2s or routes that couldn't be priced and are left out
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type feeSavingsUsage interface {
+	GetGasUsage(ctx context.Context, userID uuid.UUID, address string, from, to time.Time) ([]models.GasUsage, error)
+}
+
+type feeSavingsBridges interface {
+	GetRoutes(ctx context.Context, req BridgeRouteRequest) ([]BridgeRoute, error)
+}
+
+// FeeSavingsAdvisor compares the gas a wallet paid on mainnet with what the same gas
+// costs on L2s today, suggesting the cheapest L2 for activity that would have saved
+// enough to be worth moving, with a bridge route to get there
+type FeeSavingsAdvisor struct {
+	usage   feeSavingsUsage
+	bridges feeSavingsBridges
+	// chainCosts builds the gas pricer for a request with the caller's Alchemy key
+	chainCosts func(alchemyAPIKey string) ChainCosts
+	clock      clock.Clock
+}
+
+func NewFeeSavingsAdvisor(transactionRepo repos.TransactionRepository, bridges *BridgeService) *FeeSavingsAdvisor {
+	return &FeeSavingsAdvisor{
+		usage:   transactionRepo,
+		bridges: bridges,
+		chainCosts: func(alchemyAPIKey string) ChainCosts {
+			return blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+		},
+		clock: clock.System,
+	}
+}
+
+// Suggest looks at the gas the user's wallet at address paid over the last days and
+// suggests L2s for the activity that cost the most. bridgeAmount is the ETH, in wei,
+// suggested routes move; empty means the default.
+func (a *FeeSavingsAdvisor) Suggest(ctx context.Context, userID uuid.UUID, address string, days int, bridgeAmount, alchemyAPIKey string) (*FeeSavingsReport, error) {
+	if days == 0 {
+		days = defaultFeeSavingsDays
+	}
+	if days < 1 || days > maxFeeSavingsDays {
+		return nil, errors.BadRequest(fmt.Sprintf("Days must be between 1 and %d", maxFeeSavingsDays))
+	}
+	if bridgeAmount == "" {
+		bridgeAmount = defaultFeeSavingsBridgeAmount
+	}
+	if amount, ok := new(big.Int).SetString(bridgeAmount, 10); !ok || amount.Sign() <= 0 {
+		return nil, errors.BadRequest("Amount must be a positive integer in wei")
+	}
+
+	to := a.clock.Now().UTC()
+	report := &FeeSavingsReport{
+		Address:     address,
+		From:        to.AddDate(0, 0, -days),
+		To:          to,
+		Suggestions: []FeeSavingsSuggestion{},
+	}
+
+	usage, err := a.usage.GetGasUsage(ctx, userID, address, report.From, report.To)
+	if err != nil {
+		logger.Error("Failed to get gas usage", "address", address, "error", err)
+		return nil, errors.Internal("Failed to get gas usage")
+	}
+
+	costs := a.chainCosts(alchemyAPIKey)
+	gasCosts := make(map[int]float64)
+	for _, chainID := range feeSavingsL2Chains {
+		cost, err := gasCostUSD(ctx, costs, chainID)
+		if err != nil {
+			logger.Warn("Failed to price L2 gas", "chainId", chainID, "error", err)
+			report.Warnings = append(report.Warnings, fmt.Sprintf("No gas price for %s; it isn't compared", blockchain.GetChainName(chainID)))
+			continue
+		}
+		gasCosts[chainID] = cost
+	}
+
+	for _, u := range usage {
+		if !feeSavingsL1Chains[u.ChainID] {
+			continue
+		}
+		report.SpentUSD += u.FeeUSD
+		if suggestion := cheapestL2(u, gasCosts); suggestion != nil {
+			report.SavingsUSD += suggestion.SavingsUSD
+			report.Suggestions = append(report.Suggestions, *suggestion)
+		}
+	}
+	sort.SliceStable(report.Suggestions, func(i, j int) bool {
+		return report.Suggestions[i].SavingsUSD > report.Suggestions[j].SavingsUSD
+	})
+
+	routes := make(map[int]*BridgeRoute)
+	for i := range report.Suggestions {
+		s := &report.Suggestions[i]
+		route, ok := routes[s.ToChain]
+		if !ok {
+			route = a.bridgeRoute(ctx, s.FromChain, s.ToChain, bridgeAmount, address)
+			if route == nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("No bridge route to %s was found", blockchain.GetChainName(s.ToChain)))
+			}
+			routes[s.ToChain] = route
+		}
+		s.Route = route
+	}
+
+	return report, nil
+}
+
+// gasCostUSD returns what a unit of gas costs on a chain right now
+func gasCostUSD(ctx context.Context, costs ChainCosts, chainID int) (float64, error) {
+	gwei, err := costs.GetGasPriceGwei(ctx, chainID)
+	if err != nil {
+		return 0, err
+	}
+	price, err := costs.GetNativePriceUSD(ctx, chainID)
+	if err != nil {
+		return 0, err
+	}
+	return gwei / 1e9 * price, nil
+}
+
+// cheapestL2 prices the gas of usage on each L2 at gasCosts, per unit of gas, returning
+// the suggestion for the cheapest one or nil when it wouldn't have saved enough
+func cheapestL2(usage models.GasUsage, gasCosts map[int]float64) *FeeSavingsSuggestion {
+	var best *FeeSavingsSuggestion
+	for _, chainID := range feeSavingsL2Chains {
+		cost, ok := gasCosts[chainID]
+		if !ok {
+			continue
+		}
+		estimated := float64(usage.GasUsed) * cost
+		if best != nil && estimated >= best.EstimatedUSD {
+			continue
+		}
+		best = &FeeSavingsSuggestion{
+			FromChain:    usage.ChainID,
+			ToChain:      chainID,
+			Type:         usage.Type,
+			TxCount:      usage.TxCount,
+			SpentUSD:     usage.FeeUSD,
+			EstimatedUSD: estimated,
+			SavingsUSD:   usage.FeeUSD - estimated,
+		}
+	}
+	if best == nil || best.SavingsUSD < minFeeSavingsUSD {
+		return nil
+	}
+
+	best.Message = fmt.Sprintf("You spent $%.0f in gas on %d %s transactions on %s; the same activity on %s would have cost ~$%.2f",
+		best.SpentUSD, best.TxCount, best.Type, blockchain.GetChainName(best.FromChain), blockchain.GetChainName(best.ToChain), best.EstimatedUSD)
+	return best
+}
+
+// bridgeRoute quotes the best route bridging amount wei of ETH between chains, or nil
+// when there's none
+func (a *FeeSavingsAdvisor) bridgeRoute(ctx context.Context, fromChain, toChain int, amount, address string) *BridgeRoute {
+	routes, err := a.bridges.GetRoutes(ctx, BridgeRouteRequest{
+		FromChain:   fromChain,
+		ToChain:     toChain,
+		FromToken:   evmNativePlaceholder,
+		ToToken:     evmNativePlaceholder,
+		FromAmount:  amount,
+		UserAddress: address,
+		Slippage:    defaultEstimateSlippage,
+	})
+	if err != nil {
+		logger.Warn("Failed to quote fee savings bridge route", "fromChain", fromChain, "toChain", toChain, "error", err)
+		return nil
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	return &routes[0]
+}
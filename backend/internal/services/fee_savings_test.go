@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type feeSavingsUsageFake []models.GasUsage
+
+func (u feeSavingsUsageFake) GetGasUsage(context.Context, uuid.UUID, string, time.Time, time.Time) ([]models.GasUsage, error) {
+	return u, nil
+}
+
+// feeSavingsChainCosts prices gas per chain, in gwei, with ETH at $2000; chains without
+// a gas price fail
+type feeSavingsChainCosts map[int]float64
+
+func (c feeSavingsChainCosts) GetGasPriceGwei(_ context.Context, chainID int) (float64, error) {
+	if gwei, ok := c[chainID]; ok {
+		return gwei, nil
+	}
+	return 0, errors.Internal("no gas price")
+}
+
+func (feeSavingsChainCosts) GetNativePriceUSD(context.Context, int) (float64, error) {
+	return 2000, nil
+}
+
+func newFeeSavingsFixture(usage []models.GasUsage, costs feeSavingsChainCosts, bridges *estimateBridges) *FeeSavingsAdvisor {
+	return &FeeSavingsAdvisor{
+		usage:      feeSavingsUsageFake(usage),
+		bridges:    bridges,
+		chainCosts: func(string) ChainCosts { return costs },
+		clock:      clock.NewFake(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+func TestFeeSavingsAdvisorSuggestsCheapestL2(t *testing.T) {
+	bridges := &estimateBridges{routes: func(req BridgeRouteRequest) ([]BridgeRoute, error) {
+		return []BridgeRoute{{Provider: "lifi", ToChain: req.ToChain, FromAmount: req.FromAmount}}, nil
+	}}
+	usage := []models.GasUsage{
+		// 10M gas at 20 gwei and $2000 is $400
+		{ChainID: 1, Type: "swap", TxCount: 40, GasUsed: 10_000_000, FeeUSD: 400},
+		// Too little spent to be worth moving
+		{ChainID: 1, Type: "approve", TxCount: 2, GasUsed: 100_000, FeeUSD: 4},
+		// L2 activity isn't compared
+		{ChainID: 42161, Type: "swap", TxCount: 5, GasUsed: 5_000_000, FeeUSD: 1},
+	}
+	a := newFeeSavingsFixture(usage, feeSavingsChainCosts{42161: 0.01, 10: 0.05}, bridges)
+
+	report, err := a.Suggest(context.Background(), uuid.New(), estimateUser, 0, "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), report.From)
+	assert.InDelta(t, 404, report.SpentUSD, 1e-9)
+	require.Len(t, report.Suggestions, 1)
+	s := report.Suggestions[0]
+	assert.Equal(t, 1, s.FromChain)
+	assert.Equal(t, 42161, s.ToChain)
+	assert.Equal(t, "swap", s.Type)
+	// 10M gas at 0.01 gwei and $2000 is $0.20
+	assert.InDelta(t, 0.2, s.EstimatedUSD, 1e-9)
+	assert.InDelta(t, 399.8, s.SavingsUSD, 1e-9)
+	assert.InDelta(t, 399.8, report.SavingsUSD, 1e-9)
+	assert.Equal(t, "You spent $400 in gas on 40 swap transactions on Ethereum; the same activity on Arbitrum would have cost ~$0.20", s.Message)
+
+	require.NotNil(t, s.Route)
+	require.Len(t, bridges.calls, 1)
+	assert.Equal(t, defaultFeeSavingsBridgeAmount, bridges.calls[0].FromAmount)
+	assert.Equal(t, evmNativePlaceholder, bridges.calls[0].FromToken)
+	assert.Empty(t, report.Warnings)
+}
+
+func TestFeeSavingsAdvisorWarnings(t *testing.T) {
+	bridges := &estimateBridges{routes: func(BridgeRouteRequest) ([]BridgeRoute, error) {
+		return nil, errors.BadRequest("No bridge routes found")
+	}}
+	usage := []models.GasUsage{{ChainID: 1, Type: "send", TxCount: 10, GasUsed: 2_000_000, FeeUSD: 80}}
+	a := newFeeSavingsFixture(usage, feeSavingsChainCosts{10: 0.05}, bridges)
+
+	report, err := a.Suggest(context.Background(), uuid.New(), estimateUser, 30, "", "")
+	require.NoError(t, err)
+
+	require.Len(t, report.Suggestions, 1)
+	assert.Equal(t, 10, report.Suggestions[0].ToChain)
+	assert.Nil(t, report.Suggestions[0].Route)
+	assert.Equal(t, []string{
+		"No gas price for Arbitrum; it isn't compared",
+		"No bridge route to Optimism was found",
+	}, report.Warnings)
+}
+
+func TestFeeSavingsAdvisorValidation(t *testing.T) {
+	a := newFeeSavingsFixture(nil, feeSavingsChainCosts{}, &estimateBridges{})
+
+	_, err := a.Suggest(context.Background(), uuid.New(), estimateUser, 400, "", "")
+	assert.Error(t, err)
+	_, err = a.Suggest(context.Background(), uuid.New(), estimateUser, 30, "-1", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/feeds"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultFeedPageSize and maxFeedPageSize bound a page of the news feed
+	defaultFeedPageSize = 20
+	maxFeedPageSize     = 100
+	// maxFeedSourceTokens caps how many tokens a source can be tied to
+	maxFeedSourceTokens = 20
+)
+
+// FeedService serves the news feed of protocol blogs and governance forums, and lets
+// admins manage its sources. The sources are read by the feed ingest job.
+type FeedService struct {
+	feedRepo     repos.FeedRepository
+	protocolRepo repos.ProtocolRepository
+}
+
+func NewFeedService(feedRepo repos.FeedRepository, protocolRepo repos.ProtocolRepository) *FeedService {
+	return &FeedService{
+		feedRepo:     feedRepo,
+		protocolRepo: protocolRepo,
+	}
+}
+
+// GetFeed returns a page of the feed, newest first, starting before the given time.
+// With holdings set, only posts about the user's positions and tokens are included.
+func (s *FeedService) GetFeed(ctx context.Context, userID uuid.UUID, holdings bool, before *time.Time, limit int) ([]*models.FeedItem, error) {
+	if limit <= 0 {
+		limit = defaultFeedPageSize
+	}
+	if limit > maxFeedPageSize {
+		limit = maxFeedPageSize
+	}
+	filter := repos.FeedItemFilter{Before: before, Limit: limit}
+	if holdings {
+		filter.HoldingsOf = &userID
+	}
+
+	items, err := s.feedRepo.GetItems(ctx, filter)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return items, nil
+}
+
+// ListSources returns every feed source with its last fetch
+func (s *FeedService) ListSources(ctx context.Context) ([]*models.FeedSource, error) {
+	sources, err := s.feedRepo.GetSources(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return sources, nil
+}
+
+// CreateSource registers a feed source; the ingest job reads it on its next run
+func (s *FeedService) CreateSource(ctx context.Context, req *models.CreateFeedSourceRequest) (*models.FeedSource, error) {
+	source, appErr := validateFeedSource(req)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if req.Protocol != nil && strings.TrimSpace(*req.Protocol) != "" {
+		protocol, err := s.protocolRepo.GetBySlug(ctx, strings.TrimSpace(*req.Protocol))
+		if err != nil || protocol == nil {
+			return nil, errors.BadRequest("Unknown protocol " + *req.Protocol)
+		}
+		source.ProtocolID = &protocol.ID
+	}
+
+	if err := s.feedRepo.CreateSource(ctx, source); err != nil {
+		if err == repos.ErrFeedSourceExists {
+			return nil, errors.Conflict("A feed source with this URL already exists")
+		}
+		return nil, errors.DatabaseError(err)
+	}
+	return source, nil
+}
+
+// DeleteSource removes a feed source with its items
+func (s *FeedService) DeleteSource(ctx context.Context, id uuid.UUID) error {
+	deleted, err := s.feedRepo.DeleteSource(ctx, id)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Feed source")
+	}
+	return nil
+}
+
+// validateFeedSource checks a new source and normalizes its tokens to unique upper case
+// symbols
+func validateFeedSource(req *models.CreateFeedSourceRequest) (*models.FeedSource, *errors.AppError) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, errors.BadRequest("Name is required and must be at most 100 characters")
+	}
+	if req.Kind != feeds.KindRSS && req.Kind != feeds.KindDiscourse {
+		return nil, errors.BadRequest("Kind must be rss or discourse")
+	}
+	if !isHTTPURL(strings.TrimSpace(req.URL)) {
+		return nil, errors.BadRequest("url must be an http or https URL")
+	}
+	if len(req.Tokens) > maxFeedSourceTokens {
+		return nil, errors.BadRequest("A source can be tied to at most 20 tokens")
+	}
+
+	tokens := []string{}
+	seen := make(map[string]bool)
+	for _, token := range req.Tokens {
+		symbol := strings.ToUpper(strings.TrimSpace(token))
+		if symbol == "" || len(symbol) > 20 {
+			return nil, errors.BadRequest("Tokens must be symbols of at most 20 characters")
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			tokens = append(tokens, symbol)
+		}
+	}
+
+	return &models.FeedSource{
+		Name:    name,
+		Kind:    req.Kind,
+		URL:     strings.TrimSpace(req.URL),
+		Tokens:  tokens,
+		Enabled: true,
+	}, nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// impersonationTTL is how long an impersonation session lasts
+	impersonationTTL = 30 * time.Minute
+	// impersonationAudience marks impersonation tokens apart from sign-in tokens signed
+	// with the same secret
+	impersonationAudience = "impersonation"
+	// maxImpersonationReason is the longest reason an admin can give, in characters
+	maxImpersonationReason = 500
+	// defaultAuditLogLimit and maxAuditLogLimit bound a page of the audit log
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+// impersonationClaims are the claims of an impersonation token. The token only names
+// the session; who is impersonated and until when is read from the session each time,
+// so ending it takes effect at once.
+type impersonationClaims struct {
+	jwt.RegisteredClaims
+}
+
+// ImpersonationService lets admins view a user's data read-only for support. An admin
+// opens a session with a reason and gets a token to send with their own sign-in; every
+// request made with it is written to the audit log.
+type ImpersonationService struct {
+	auditRepo repos.AuditRepository
+	userRepo  repos.UserRepository
+	secret    []byte
+	clock     clock.Clock
+}
+
+func NewImpersonationService(auditRepo repos.AuditRepository, userRepo repos.UserRepository, jwtSecret string) *ImpersonationService {
+	return &ImpersonationService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+		secret:    []byte(jwtSecret),
+		clock:     clock.System,
+	}
+}
+
+// Start opens a session for the admin to impersonate the user. Admins can't be
+// impersonated.
+func (s *ImpersonationService) Start(ctx context.Context, adminID uuid.UUID, req *models.StartImpersonationRequest) (*models.ImpersonationGrant, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.BadRequest("A reason is required")
+	}
+	if utf8.RuneCountInString(reason) > maxImpersonationReason {
+		return nil, errors.BadRequest("Reason must be at most 500 characters")
+	}
+	if req.UserID == adminID {
+		return nil, errors.BadRequest("You can't impersonate yourself")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, repoError(err, "User")
+	}
+	if user.IsAdmin {
+		return nil, errors.Forbidden("Admins can't be impersonated")
+	}
+
+	session := &models.ImpersonationSession{
+		AdminID:   adminID,
+		UserID:    user.ID,
+		Reason:    reason,
+		ExpiresAt: s.clock.Now().Add(impersonationTTL),
+	}
+	if err := s.auditRepo.CreateImpersonationSession(ctx, session); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if err := s.audit(ctx, session, models.AuditActionImpersonationStarted, "", "", map[string]interface{}{"reason": reason}); err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, impersonationClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID.String(),
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{impersonationAudience},
+			IssuedAt:  jwt.NewNumericDate(session.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+		},
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, errors.Internal("Failed to issue impersonation token")
+	}
+
+	logger.Info("Impersonation started", "adminID", adminID, "userID", user.ID, "sessionID", session.ID)
+	return &models.ImpersonationGrant{Session: session, Token: token}, nil
+}
+
+// Authorize checks an impersonation token sent by the admin, returning its session and
+// the user to act as
+func (s *ImpersonationService) Authorize(ctx context.Context, adminID uuid.UUID, token string) (*models.ImpersonationSession, *models.User, error) {
+	var claims impersonationClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return s.secret, nil
+	}, jwt.WithAudience(impersonationAudience), jwt.WithTimeFunc(s.clock.Now))
+	if err != nil {
+		return nil, nil, errors.Unauthorized("Invalid impersonation token")
+	}
+	sessionID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, nil, errors.Unauthorized("Invalid impersonation token")
+	}
+
+	session, err := s.auditRepo.GetImpersonationSession(ctx, sessionID)
+	if err != nil {
+		return nil, nil, errors.DatabaseError(err)
+	}
+	// Tokens are only good for the admin who opened the session
+	if session == nil || session.AdminID != adminID {
+		return nil, nil, errors.Unauthorized("Invalid impersonation token")
+	}
+	if session.EndedAt != nil || !s.clock.Now().Before(session.ExpiresAt) {
+		return nil, nil, errors.Unauthorized("Impersonation session has ended")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, errors.Unauthorized("Impersonated user not found")
+	}
+	return session, user, nil
+}
+
+// RecordRequest writes a request made while impersonating to the audit log
+func (s *ImpersonationService) RecordRequest(ctx context.Context, session *models.ImpersonationSession, method, path string) error {
+	return s.audit(ctx, session, models.AuditActionImpersonatedRequest, method, path, nil)
+}
+
+// End closes one of the admin's sessions, revoking its token
+func (s *ImpersonationService) End(ctx context.Context, adminID, sessionID uuid.UUID) error {
+	session, err := s.auditRepo.GetImpersonationSession(ctx, sessionID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if session == nil || session.AdminID != adminID {
+		return errors.NotFound("Impersonation session")
+	}
+
+	ended, err := s.auditRepo.EndImpersonationSession(ctx, sessionID, adminID, s.clock.Now())
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !ended {
+		return errors.NotFound("Impersonation session")
+	}
+	return s.audit(ctx, session, models.AuditActionImpersonationEnded, "", "", nil)
+}
+
+// ListAuditLog returns a page of the audit log, newest first
+func (s *ImpersonationService) ListAuditLog(ctx context.Context, filter repos.AuditLogFilter) ([]*models.AuditLogEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLogLimit
+	}
+	if filter.Limit > maxAuditLogLimit {
+		filter.Limit = maxAuditLogLimit
+	}
+	entries, err := s.auditRepo.List(ctx, filter)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return entries, nil
+}
+
+func (s *ImpersonationService) audit(ctx context.Context, session *models.ImpersonationSession, action, method, path string, metadata map[string]interface{}) error {
+	entry := &models.AuditLogEntry{
+		ActorID:      session.AdminID,
+		Action:       action,
+		TargetUserID: &session.UserID,
+		SessionID:    &session.ID,
+		Metadata:     metadata,
+	}
+	if method != "" {
+		entry.Method, entry.Path = &method, &path
+	}
+	if err := s.auditRepo.Record(ctx, entry); err != nil {
+		logger.Error("Failed to write audit log", "error", err, "action", action, "sessionID", session.ID)
+		return errors.Internal("Failed to write audit log")
+	}
+	return nil
+}
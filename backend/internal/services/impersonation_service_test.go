@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAuditRepo struct {
+	sessions map[uuid.UUID]*models.ImpersonationSession
+	entries  []*models.AuditLogEntry
+	now      func() time.Time
+}
+
+func (r *memoryAuditRepo) Record(ctx context.Context, entry *models.AuditLogEntry) error {
+	entry.ID = uuid.New()
+	entry.CreatedAt = r.now()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *memoryAuditRepo) List(ctx context.Context, filter repos.AuditLogFilter) ([]*models.AuditLogEntry, error) {
+	return r.entries, nil
+}
+
+func (r *memoryAuditRepo) CreateImpersonationSession(ctx context.Context, session *models.ImpersonationSession) error {
+	session.ID = uuid.New()
+	session.CreatedAt = r.now()
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *memoryAuditRepo) GetImpersonationSession(ctx context.Context, id uuid.UUID) (*models.ImpersonationSession, error) {
+	return r.sessions[id], nil
+}
+
+func (r *memoryAuditRepo) EndImpersonationSession(ctx context.Context, id, adminID uuid.UUID, at time.Time) (bool, error) {
+	session := r.sessions[id]
+	if session == nil || session.AdminID != adminID || session.EndedAt != nil {
+		return false, nil
+	}
+	session.EndedAt = &at
+	return true, nil
+}
+
+type memoryUserRepo struct {
+	repos.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (r *memoryUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, fmt.Errorf("user %w", repos.ErrNotFound)
+}
+
+func TestImpersonationService(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	admin := &models.User{ID: uuid.New(), IsAdmin: true}
+	otherAdmin := &models.User{ID: uuid.New(), IsAdmin: true}
+	user := &models.User{ID: uuid.New(), Address: "0xuser"}
+
+	auditRepo := &memoryAuditRepo{sessions: map[uuid.UUID]*models.ImpersonationSession{}, now: clk.Now}
+	service := NewImpersonationService(auditRepo, &memoryUserRepo{users: map[uuid.UUID]*models.User{
+		admin.ID: admin, otherAdmin.ID: otherAdmin, user.ID: user,
+	}}, "secret")
+	service.clock = clk
+
+	status := func(err error) int {
+		appErr, ok := err.(*errors.AppError)
+		require.True(t, ok, "expected an AppError, got %v", err)
+		return appErr.Status
+	}
+
+	_, err := service.Start(ctx, admin.ID, &models.StartImpersonationRequest{UserID: user.ID})
+	assert.Equal(t, 400, status(err), "a reason is required")
+	_, err = service.Start(ctx, admin.ID, &models.StartImpersonationRequest{UserID: otherAdmin.ID, Reason: "ticket 1"})
+	assert.Equal(t, 403, status(err), "admins can't be impersonated")
+	_, err = service.Start(ctx, admin.ID, &models.StartImpersonationRequest{UserID: uuid.New(), Reason: "ticket 1"})
+	assert.Equal(t, 404, status(err))
+
+	grant, err := service.Start(ctx, admin.ID, &models.StartImpersonationRequest{UserID: user.ID, Reason: " ticket 42 "})
+	require.NoError(t, err)
+	assert.Equal(t, "ticket 42", grant.Session.Reason)
+	require.Len(t, auditRepo.entries, 1)
+	assert.Equal(t, models.AuditActionImpersonationStarted, auditRepo.entries[0].Action)
+
+	session, impersonated, err := service.Authorize(ctx, admin.ID, grant.Token)
+	require.NoError(t, err)
+	assert.Equal(t, grant.Session.ID, session.ID)
+	assert.Equal(t, user.ID, impersonated.ID)
+
+	_, _, err = service.Authorize(ctx, otherAdmin.ID, grant.Token)
+	assert.Equal(t, 401, status(err), "tokens belong to the admin who opened the session")
+	_, _, err = service.Authorize(ctx, admin.ID, grant.Token+"x")
+	assert.Equal(t, 401, status(err))
+
+	require.NoError(t, service.RecordRequest(ctx, session, "GET", "/api/v1/portfolio"))
+	assert.Equal(t, models.AuditActionImpersonatedRequest, auditRepo.entries[1].Action)
+
+	assert.Equal(t, 404, status(service.End(ctx, otherAdmin.ID, session.ID)))
+	require.NoError(t, service.End(ctx, admin.ID, session.ID))
+	_, _, err = service.Authorize(ctx, admin.ID, grant.Token)
+	assert.Equal(t, 401, status(err), "ending the session revokes its token")
+
+	expiring, err := service.Start(ctx, admin.ID, &models.StartImpersonationRequest{UserID: user.ID, Reason: "ticket 43"})
+	require.NoError(t, err)
+	clk.Advance(impersonationTTL)
+	_, _, err = service.Authorize(ctx, admin.ID, expiring.Token)
+	assert.Equal(t, 401, status(err), "sessions expire")
+}
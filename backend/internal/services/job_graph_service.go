@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/jobgraph"
+)
+
+// workerJobs are the jobs the worker schedules. Schedules have a leading seconds field.
+// A job that reads what another writes declares it in DependsOn and shares its
+// schedule, or a multiple of it, so that the two run in order in the same tick. Jobs
+// list the providers whose quota they spend in Providers, to be held back while one has
+// run out; jobs that must keep running, like alert evaluation, don't.
+var workerJobs = []jobgraph.Job{
+	{Name: "price-refresh", Schedule: "0 */10 * * * *", Providers: []string{external.ProviderCoinGecko},
+		Description: "Token prices, trending coins and yield pool APYs every 10 minutes"},
+	{Name: "wallet-valuation", Schedule: "0 */5 * * * *", DependsOn: []string{"price-refresh"},
+		Description: "Wallet valuation snapshots every 5 minutes, from the refreshed prices"},
+	{Name: "protocol-tvl-sync", Schedule: "0 55 * * * *",
+		Description: "Protocol TVL snapshots every hour, for liquidity alerts"},
+	{Name: "alert-evaluator", Schedule: "0 */5 * * * *", DependsOn: []string{"price-refresh", "wallet-valuation", "protocol-tvl-sync"},
+		Description: "Alert evaluation every 5 minutes, after the prices, pools, valuations and TVLs it reads"},
+	{Name: "gas-fee-backfill", Schedule: "0 30 * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Gas fee backfill every hour"},
+	{Name: "nft-transfer-sync", Schedule: "0 15 */6 * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "NFT transfer sync every 6 hours"},
+	{Name: "notification-queue", Schedule: "0 * * * * *",
+		Description: "Deliver notifications held back by quiet hours every minute"},
+	{Name: "notification-outbox", Schedule: "30 * * * * *",
+		Description: "Retry alert notifications left in the outbox every minute, offset from the queue job"},
+	{Name: "alert-escalation", Schedule: "10 * * * * *",
+		Description: "Send the next step of unacknowledged team escalations every minute"},
+	{Name: "reorg-detection", Schedule: "0 */2 * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Reorg detection every 2 minutes, well inside the shortest reorg window"},
+	{Name: "confirmation-tracker", Schedule: "*/30 * * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Confirmation tracking for pending transactions every 30 seconds"},
+	{Name: "token-metadata", Schedule: "0 45 * * * *", Providers: []string{external.ProviderCoinGecko},
+		Description: "Token metadata enrichment every hour, in batches that respect CoinGecko's rate limit"},
+	{Name: "liquidation-monitor", Schedule: "0 * * * * *",
+		Description: "Liquidation risk monitoring every minute for health factor alerts"},
+	{Name: "derivative-position-sync", Schedule: "0 2-59/5 * * * *",
+		Description: "Perpetual position and funding sync every 5 minutes, offset from the alert evaluator"},
+	{Name: "bitcoin-sync", Schedule: "0 4-59/10 * * * *",
+		Description: "Bitcoin account sync every 10 minutes, roughly one block interval"},
+	{Name: "exchange-sync", Schedule: "0 7-59/15 * * * *",
+		Description: "Exchange balance and trade sync every 15 minutes, when credentials can be decrypted"},
+	{Name: "monthly-statements", Schedule: "0 20 * * * *",
+		Description: "Monthly statements hourly; each run only generates those still due for last month"},
+	{Name: "upload-retention", Schedule: "0 40 * * * *",
+		Description: "Expired uploads hourly"},
+	{Name: "internal-transfer-match", Schedule: "0 35 * * * *",
+		Description: "Pair transfers between users' own wallets hourly"},
+	{Name: "wallet-backfill", Schedule: "15 * * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Transaction history backfills every minute; each run queues newly added wallets and advances the active backfills"},
+	{Name: "alchemy-webhook-events", Schedule: "*/10 * * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Alchemy webhook deliveries every 10 seconds, when webhooks are configured"},
+	{Name: "alchemy-webhook-subscriptions", Schedule: "25 * * * * *",
+		Description: "The addresses each Alchemy webhook watches every minute, when webhooks are configured"},
+	{Name: "balance-refresh", Schedule: "20 * * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Bulk balance refreshes queued by admins every minute, until the provider throttles"},
+	{Name: "wallet-account-type", Schedule: "45 * * * * *", Providers: []string{external.ProviderAlchemy},
+		Description: "Detect the account type of newly added wallets every minute, so contract wallets are known before they sign anything"},
+	{Name: "wallet-removal", Schedule: "50 * * * * *",
+		Description: "Clear the data of wallets users removed every minute, a step at a time"},
+	{Name: "alert-target-reconcile", Schedule: "0 50 * * * *", DependsOn: []string{"price-refresh"},
+		Description: "Expire alerts whose yield pool or token was deactivated or delisted every hour, after the pools are refreshed"},
+	{Name: "token-holders", Schedule: "0 15 * * * *",
+		Description: "Refresh the largest holders of held tokens from Etherscan every hour, when an API key is set"},
+	{Name: "contract-metadata", Schedule: "0 10 * * * *",
+		Description: "Look up held tokens' and allowance spenders' contracts on Etherscan every hour, when an API key is set"},
+	{Name: "pool-migration-notices", Schedule: "0 5 * * * *",
+		Description: "Tell holders of positions in deprecated yield pools where their pool migrated to every hour"},
+	{Name: "feed-ingest", Schedule: "0 11-59/15 * * * *",
+		Description: "Ingest the news feed sources every 15 minutes"},
+	{Name: "partition-maintenance", Schedule: "0 50 3 * * *",
+		Description: "Create the coming months' alert history partitions and drop expired ones daily"},
+	{Name: "provider-call-retention", Schedule: "0 20 4 * * *",
+		Description: "Prune logged provider calls daily"},
+	{Name: "billing-grace", Schedule: "0 25 * * * *",
+		Description: "Downgrade subscribers whose grace period ran out, hourly"},
+	{Name: "compliance-screening", Schedule: "0 0 * * * *",
+		Description: "Screen wallets' counterparties against the sanctions and mixer lists hourly, when compliance screening is on"},
+	{Name: "broadcast-emails", Schedule: "0 */5 * * * *",
+		Description: "Email admins' broadcasts to their recipients every 5 minutes"},
+	{Name: "webhook-reports", Schedule: "0 * * * * *",
+		Description: "Post users' scheduled webhook reports as they come due, checking every minute"},
+	{Name: "token-list-sync", Schedule: "0 40 3 * * *",
+		Description: "Seed and refresh the tokens table from the Uniswap and CoinGecko token lists daily"},
+}
+
+// NewWorkerJobGraph returns the graph of the jobs the worker schedules
+func NewWorkerJobGraph() (*jobgraph.Graph, error) {
+	return jobgraph.New(workerJobs)
+}
+
+// JobGraphService shows admins the worker's job graph with each job's latest run, which
+// the worker records, so the API can show it without reaching the worker
+type JobGraphService struct {
+	graph      *jobgraph.Graph
+	statusRepo repos.SystemStatusRepository
+	clock      clock.Clock
+}
+
+func NewJobGraphService(graph *jobgraph.Graph, statusRepo repos.SystemStatusRepository) *JobGraphService {
+	return &JobGraphService{
+		graph:      graph,
+		statusRepo: statusRepo,
+		clock:      clock.System,
+	}
+}
+
+// GetGraph returns the jobs in the order they run when due together
+func (s *JobGraphService) GetGraph(ctx context.Context) (*models.JobGraph, error) {
+	runs, err := s.statusRepo.GetJobRuns(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	now := s.clock.Now()
+	graph := &models.JobGraph{Jobs: make([]models.JobGraphNode, 0, len(s.graph.Jobs()))}
+	for _, job := range s.graph.Jobs() {
+		node := models.JobGraphNode{
+			Name:        job.Name,
+			Schedule:    job.Schedule,
+			Description: job.Description,
+			DependsOn:   job.DependsOn,
+			Dependents:  s.graph.Dependents(job.Name),
+			NextRunAt:   s.graph.Next(job.Name, now),
+			LastRun:     runs[job.Name],
+		}
+		if node.DependsOn == nil {
+			node.DependsOn = []string{}
+		}
+		if node.Dependents == nil {
+			node.Dependents = []string{}
+		}
+		graph.Jobs = append(graph.Jobs, node)
+	}
+	return graph, nil
+}
\ No newline at end of file
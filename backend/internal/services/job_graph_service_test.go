@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerJobGraph(t *testing.T) {
+	graph, err := NewWorkerJobGraph()
+	require.NoError(t, err)
+	assert.Len(t, graph.Jobs(), len(workerJobs))
+
+	// Every job status pages judge is scheduled
+	for _, subsystem := range statusSubsystems {
+		for _, job := range subsystem.jobs {
+			_, ok := graph.Job(job.name)
+			assert.True(t, ok, job.name)
+		}
+	}
+	assert.Equal(t, []string{"price-refresh", "wallet-valuation", "protocol-tvl-sync"}, graph.Prerequisites("alert-evaluator"))
+}
+
+func TestJobGraphService(t *testing.T) {
+	graph, err := NewWorkerJobGraph()
+	require.NoError(t, err)
+	failure := "coingecko unavailable"
+	statusRepo := &statusRepoStub{runs: map[string]*models.JobRun{
+		"price-refresh": {JobName: "price-refresh", LastError: &failure},
+	}}
+	service := NewJobGraphService(graph, statusRepo)
+	service.clock = clock.NewFake(time.Date(2025, 3, 1, 12, 3, 0, 0, time.UTC))
+
+	result, err := service.GetGraph(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Jobs, len(workerJobs))
+
+	prices := result.Jobs[0]
+	assert.Equal(t, "price-refresh", prices.Name)
+	assert.Empty(t, prices.DependsOn)
+	assert.ElementsMatch(t, []string{"wallet-valuation", "alert-evaluator", "alert-target-reconcile"}, prices.Dependents)
+	assert.Equal(t, time.Date(2025, 3, 1, 12, 10, 0, 0, time.UTC), prices.NextRunAt)
+	require.NotNil(t, prices.LastRun)
+	assert.Equal(t, failure, *prices.LastRun.LastError)
+
+	positions := map[string]int{}
+	for i, job := range result.Jobs {
+		positions[job.Name] = i
+	}
+	assert.Less(t, positions["wallet-valuation"], positions["alert-evaluator"])
+	assert.Less(t, positions["protocol-tvl-sync"], positions["alert-evaluator"])
+	assert.Less(t, positions["price-refresh"], positions["alert-target-reconcile"])
+	assert.Nil(t, result.Jobs[positions["alert-evaluator"]].LastRun)
+}
\ No newline at end of file
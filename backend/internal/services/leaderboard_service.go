@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// FeatureFlagLeaderboards turns on the leaderboards and platform stats when its value has
+// "enabled": true. They are off until an admin sets it.
+const FeatureFlagLeaderboards = "leaderboards"
+
+const (
+	// maxLeaderboardEntries is the most entries a board shows
+	maxLeaderboardEntries = 100
+	// leaderboardCacheTTL is how long computed boards and stats are served before being
+	// recomputed; they scan every participant's holdings
+	leaderboardCacheTTL = 5 * time.Minute
+	// leaderboardMinValueUSD keeps portfolios too small for their ratios to mean much off
+	// the boards
+	leaderboardMinValueUSD = 100
+	// platformStatsMinHolders is how many users must hold a token before it's listed
+	platformStatsMinHolders = 5
+	// platformStatsTokens is how many of the most held tokens the stats list
+	platformStatsTokens = 20
+)
+
+// LeaderboardService ranks the users who opted in under anonymous aliases and aggregates
+// platform-wide stats, both from wallets their owners haven't made private
+type LeaderboardService struct {
+	leaderboardRepo repos.LeaderboardRepository
+	featureFlagRepo repos.FeatureFlagRepository
+	clock           clock.Clock
+
+	mu     sync.Mutex
+	boards map[string]*models.Leaderboard
+	stats  *models.PlatformStats
+}
+
+func NewLeaderboardService(leaderboardRepo repos.LeaderboardRepository, featureFlagRepo repos.FeatureFlagRepository) *LeaderboardService {
+	return &LeaderboardService{
+		leaderboardRepo: leaderboardRepo,
+		featureFlagRepo: featureFlagRepo,
+		clock:           clock.System,
+		boards:          make(map[string]*models.Leaderboard),
+	}
+}
+
+// Enabled reports whether an admin has turned the leaderboards on
+func (s *LeaderboardService) Enabled(ctx context.Context) bool {
+	flag, err := s.featureFlagRepo.GetByName(ctx, FeatureFlagLeaderboards)
+	if err != nil || flag == nil {
+		return false
+	}
+	enabled, _ := flag.Value["enabled"].(bool)
+	return enabled
+}
+
+// GetLeaderboard returns the top limit entries of a board
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, board string, limit int) (*models.Leaderboard, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Leaderboards are disabled")
+	}
+	if board != models.LeaderboardReturns && board != models.LeaderboardDiversified {
+		return nil, errors.NotFound("Leaderboard")
+	}
+	if limit <= 0 || limit > maxLeaderboardEntries {
+		limit = maxLeaderboardEntries
+	}
+
+	now := s.clock.Now()
+	s.mu.Lock()
+	cached := s.boards[board]
+	s.mu.Unlock()
+	if cached == nil || now.Sub(cached.GeneratedAt) >= leaderboardCacheTTL {
+		fresh := &models.Leaderboard{Board: board, GeneratedAt: now}
+		var err error
+		switch board {
+		case models.LeaderboardReturns:
+			since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+			fresh.Since = &since
+			fresh.Entries, err = s.leaderboardRepo.TopReturns(ctx, since, leaderboardMinValueUSD, maxLeaderboardEntries)
+		case models.LeaderboardDiversified:
+			fresh.Entries, err = s.leaderboardRepo.TopDiversified(ctx, leaderboardMinValueUSD, maxLeaderboardEntries)
+		}
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		s.mu.Lock()
+		s.boards[board] = fresh
+		s.mu.Unlock()
+		cached = fresh
+	}
+
+	result := *cached
+	if len(result.Entries) > limit {
+		result.Entries = result.Entries[:limit]
+	}
+	return &result, nil
+}
+
+// GetPlatformStats returns the platform-wide totals and most held tokens
+func (s *LeaderboardService) GetPlatformStats(ctx context.Context) (*models.PlatformStats, error) {
+	if !s.Enabled(ctx) {
+		return nil, errors.Forbidden("Platform stats are disabled")
+	}
+
+	now := s.clock.Now()
+	s.mu.Lock()
+	cached := s.stats
+	s.mu.Unlock()
+	if cached != nil && now.Sub(cached.GeneratedAt) < leaderboardCacheTTL {
+		return cached, nil
+	}
+
+	stats, err := s.leaderboardRepo.GetPlatformStats(ctx, platformStatsMinHolders, platformStatsTokens)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	stats.GeneratedAt = now
+	s.mu.Lock()
+	s.stats = stats
+	s.mu.Unlock()
+	return stats, nil
+}
+
+// GetParticipation returns the user's participant record, or nil when they haven't
+// opted in
+func (s *LeaderboardService) GetParticipation(ctx context.Context, userID uuid.UUID) (*models.LeaderboardParticipant, error) {
+	participant, err := s.leaderboardRepo.GetParticipant(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return participant, nil
+}
+
+// SetParticipation opts the user in under a new random alias, or out. Opting out and in
+// again gives a new alias.
+func (s *LeaderboardService) SetParticipation(ctx context.Context, userID uuid.UUID, optIn bool) (*models.LeaderboardParticipant, error) {
+	if !optIn {
+		if err := s.leaderboardRepo.OptOut(ctx, userID); err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		s.invalidate()
+		return nil, nil
+	}
+
+	// Aliases are random, so a clash is unlikely and a retry or two settles it
+	for attempt := 0; attempt < 3; attempt++ {
+		alias, err := newLeaderboardAlias()
+		if err != nil {
+			logger.Error("Failed to generate leaderboard alias", "error", err)
+			return nil, errors.Internal("Failed to opt in")
+		}
+		participant, err := s.leaderboardRepo.OptIn(ctx, userID, alias)
+		if err == repos.ErrLeaderboardAliasTaken {
+			continue
+		}
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		return participant, nil
+	}
+	return nil, errors.Internal("Failed to opt in")
+}
+
+// ListParticipants lists every participant for admins
+func (s *LeaderboardService) ListParticipants(ctx context.Context) ([]*models.LeaderboardParticipant, error) {
+	participants, err := s.leaderboardRepo.ListParticipants(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return participants, nil
+}
+
+// SetParticipantHidden takes a participant off the boards, or puts them back. The boards
+// are recomputed on the next read.
+func (s *LeaderboardService) SetParticipantHidden(ctx context.Context, userID uuid.UUID, hidden bool) error {
+	found, err := s.leaderboardRepo.SetHidden(ctx, userID, hidden)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !found {
+		return errors.NotFound("Leaderboard participant")
+	}
+	s.invalidate()
+	return nil
+}
+
+// invalidate drops the cached boards so changes to who is on them show at once
+func (s *LeaderboardService) invalidate() {
+	s.mu.Lock()
+	s.boards = make(map[string]*models.Leaderboard)
+	s.mu.Unlock()
+}
+
+// newLeaderboardAlias returns a random alias that says nothing about its user
+func newLeaderboardAlias() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "anon-" + hex.EncodeToString(b), nil
+}
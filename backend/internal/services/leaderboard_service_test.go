@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryFeatureFlagRepo struct {
+	repos.FeatureFlagRepository
+	flags map[string]*models.FeatureFlag
+}
+
+func (r *memoryFeatureFlagRepo) GetByName(ctx context.Context, name string) (*models.FeatureFlag, error) {
+	return r.flags[name], nil
+}
+
+type memoryLeaderboardRepo struct {
+	repos.LeaderboardRepository
+	returns      []*models.LeaderboardEntry
+	returnsSince time.Time
+	queries      int
+	hidden       map[uuid.UUID]bool
+}
+
+func (r *memoryLeaderboardRepo) TopReturns(ctx context.Context, since time.Time, minValueUSD float64, limit int) ([]*models.LeaderboardEntry, error) {
+	r.queries++
+	r.returnsSince = since
+	return r.returns, nil
+}
+
+func (r *memoryLeaderboardRepo) SetHidden(ctx context.Context, userID uuid.UUID, hidden bool) (bool, error) {
+	if _, ok := r.hidden[userID]; !ok {
+		return false, nil
+	}
+	r.hidden[userID] = hidden
+	return true, nil
+}
+
+func TestLeaderboardServiceGetLeaderboard(t *testing.T) {
+	flags := &memoryFeatureFlagRepo{flags: map[string]*models.FeatureFlag{}}
+	participant := uuid.New()
+	repo := &memoryLeaderboardRepo{
+		returns: []*models.LeaderboardEntry{
+			{Rank: 1, Alias: "anon-1", Score: 42},
+			{Rank: 2, Alias: "anon-2", Score: 7},
+			{Rank: 3, Alias: "anon-3", Score: -3},
+		},
+		hidden: map[uuid.UUID]bool{participant: false},
+	}
+	service := NewLeaderboardService(repo, flags)
+	clk := clock.NewFake(time.Date(2026, 3, 18, 12, 0, 0, 0, time.UTC))
+	service.clock = clk
+	ctx := context.Background()
+
+	_, err := service.GetLeaderboard(ctx, models.LeaderboardReturns, 10)
+	require.Error(t, err, "leaderboards are off until an admin turns them on")
+	assert.Equal(t, http.StatusForbidden, err.(*errors.AppError).Status)
+
+	flags.flags[FeatureFlagLeaderboards] = &models.FeatureFlag{Name: FeatureFlagLeaderboards, Value: map[string]interface{}{"enabled": true}}
+
+	_, err = service.GetLeaderboard(ctx, "richest", 10)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, err.(*errors.AppError).Status)
+
+	board, err := service.GetLeaderboard(ctx, models.LeaderboardReturns, 2)
+	require.NoError(t, err)
+	require.Len(t, board.Entries, 2)
+	assert.Equal(t, "anon-1", board.Entries[0].Alias)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), repo.returnsSince, "returns cover this month")
+
+	board, err = service.GetLeaderboard(ctx, models.LeaderboardReturns, 10)
+	require.NoError(t, err)
+	assert.Len(t, board.Entries, 3, "a cached board still serves larger limits")
+	assert.Equal(t, 1, repo.queries)
+
+	require.NoError(t, service.SetParticipantHidden(ctx, participant, true))
+	assert.True(t, repo.hidden[participant])
+	_, err = service.GetLeaderboard(ctx, models.LeaderboardReturns, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.queries, "hiding a participant recomputes the board")
+
+	clk.Advance(leaderboardCacheTTL)
+	_, err = service.GetLeaderboard(ctx, models.LeaderboardReturns, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, repo.queries)
+
+	err = service.SetParticipantHidden(ctx, uuid.New(), true)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, err.(*errors.AppError).Status)
+}
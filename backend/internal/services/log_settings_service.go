@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// FeatureFlagLogSettings holds the log settings set through the admin API
+const FeatureFlagLogSettings = "log_settings"
+
+const (
+	// maxLogSettingsDuration is the longest changed log settings may apply for
+	maxLogSettingsDuration = 24 * time.Hour
+	// defaultLogSettingsDuration applies when a change doesn't say how long it's for
+	defaultLogSettingsDuration = time.Hour
+	// maxDebugSampleRate is the sparsest debug sampling that can be set
+	maxDebugSampleRate = 10000
+)
+
+// logLevels are the levels the settings may name
+var logLevels = map[string]bool{
+	"trace": true,
+	"debug": true,
+	"info":  true,
+	"warn":  true,
+	"error": true,
+}
+
+// LogSettingsService applies the admin-set log level and debug sampling in this
+// process. They're stored as a feature flag so every API and worker instance follows
+// them: changes made through the service apply at once here, and other processes pick
+// them up on their next reload, or straight away on SIGHUP. Expired or reset settings
+// fall back to the configured defaults.
+type LogSettingsService struct {
+	featureFlagRepo repos.FeatureFlagRepository
+	defaults        models.LogSettings
+	clock           clock.Clock
+}
+
+func NewLogSettingsService(featureFlagRepo repos.FeatureFlagRepository, defaultLevel string, defaultSampleRate int) *LogSettingsService {
+	defaultLevel = strings.ToLower(defaultLevel)
+	if defaultLevel == "warning" {
+		defaultLevel = "warn"
+	}
+	if !logLevels[defaultLevel] {
+		defaultLevel = "info"
+	}
+	if defaultSampleRate < 1 {
+		defaultSampleRate = 1
+	}
+	return &LogSettingsService{
+		featureFlagRepo: featureFlagRepo,
+		defaults:        models.LogSettings{Level: defaultLevel, DebugSampleRate: defaultSampleRate},
+		clock:           clock.System,
+	}
+}
+
+// Get returns the settings in force
+func (s *LogSettingsService) Get(ctx context.Context) (*models.LogSettings, error) {
+	flag, err := s.featureFlagRepo.GetByName(ctx, FeatureFlagLogSettings)
+	if err != nil && !stderrors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.DatabaseError(err)
+	}
+	return effectiveLogSettings(flag, s.defaults, s.clock.Now()), nil
+}
+
+// Load applies the settings in force to this process
+func (s *LogSettingsService) Load(ctx context.Context) error {
+	settings, err := s.Get(ctx)
+	if err != nil {
+		return err
+	}
+	applyLogSettings(settings)
+	return nil
+}
+
+// Watch reloads the settings every interval, and whenever the process gets SIGHUP,
+// until ctx is done. A failed reload keeps the settings last applied.
+func (s *LogSettingsService) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Info("Reloading log settings on SIGHUP")
+			s.reload(ctx)
+		case <-ticker.C:
+			s.reload(ctx)
+		}
+	}
+}
+
+// Update stores new settings for the requested duration and applies them here
+func (s *LogSettingsService) Update(ctx context.Context, updatedBy uuid.UUID, req *models.UpdateLogSettingsRequest) (*models.LogSettings, error) {
+	level := strings.ToLower(req.Level)
+	if !logLevels[level] {
+		return nil, errors.BadRequest("Unknown log level; expected trace, debug, info, warn or error")
+	}
+	sampleRate := s.defaults.DebugSampleRate
+	if req.DebugSampleRate != nil {
+		sampleRate = *req.DebugSampleRate
+	}
+	if sampleRate < 1 || sampleRate > maxDebugSampleRate {
+		return nil, errors.BadRequest(fmt.Sprintf("Debug sample rate must be between 1 and %d", maxDebugSampleRate))
+	}
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	if duration == 0 {
+		duration = defaultLogSettingsDuration
+	}
+	if duration < 0 || duration > maxLogSettingsDuration {
+		return nil, errors.BadRequest(fmt.Sprintf("Duration must be between 1 and %d minutes", int(maxLogSettingsDuration/time.Minute)))
+	}
+
+	expiresAt := s.clock.Now().Add(duration).UTC()
+	settings := &models.LogSettings{
+		Level:           level,
+		DebugSampleRate: sampleRate,
+		ExpiresAt:       &expiresAt,
+		UpdatedBy:       &updatedBy,
+	}
+	value, err := logSettingsValue(settings)
+	if err != nil {
+		return nil, errors.Internal("Failed to save log settings")
+	}
+	if err := s.featureFlagRepo.Upsert(ctx, &models.FeatureFlag{Name: FeatureFlagLogSettings, Value: value}); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	logger.Info("Log settings changed", "level", level, "debugSampleRate", sampleRate,
+		"expiresAt", expiresAt, "updatedBy", updatedBy)
+
+	applyLogSettings(settings)
+	return settings, nil
+}
+
+// Reset goes back to the configured defaults
+func (s *LogSettingsService) Reset(ctx context.Context) (*models.LogSettings, error) {
+	// Delete fails when nothing is stored, which leaves the defaults in force already
+	if err := s.featureFlagRepo.Delete(ctx, FeatureFlagLogSettings); err != nil {
+		if _, getErr := s.featureFlagRepo.GetByName(ctx, FeatureFlagLogSettings); !stderrors.Is(getErr, pgx.ErrNoRows) {
+			return nil, errors.DatabaseError(err)
+		}
+	}
+	logger.Info("Log settings reset", "level", s.defaults.Level)
+
+	settings := s.defaults
+	applyLogSettings(&settings)
+	return &settings, nil
+}
+
+func (s *LogSettingsService) reload(ctx context.Context) {
+	if err := s.Load(ctx); err != nil {
+		logger.Warn("Failed to reload log settings", "error", err)
+	}
+}
+
+// effectiveLogSettings returns the stored settings, or the defaults when there are none
+// or they've expired
+func effectiveLogSettings(flag *models.FeatureFlag, defaults models.LogSettings, now time.Time) *models.LogSettings {
+	settings := defaults
+	if flag == nil {
+		return &settings
+	}
+
+	var stored models.LogSettings
+	data, err := json.Marshal(flag.Value)
+	if err == nil {
+		err = json.Unmarshal(data, &stored)
+	}
+	if err != nil || !logLevels[stored.Level] {
+		logger.Warn("Ignoring invalid stored log settings", "error", err)
+		return &settings
+	}
+	if stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt) {
+		return &settings
+	}
+	if stored.DebugSampleRate < 1 {
+		stored.DebugSampleRate = 1
+	}
+	return &stored
+}
+
+func logSettingsValue(settings *models.LogSettings) (map[string]interface{}, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// applyLogSettings sets the level and sampling, logging only when the level changes so
+// scheduled reloads stay quiet
+func applyLogSettings(settings *models.LogSettings) {
+	logger.SetDebugSampling(settings.DebugSampleRate)
+	previous := logger.Level()
+	if previous == settings.Level {
+		return
+	}
+	if err := logger.SetLevel(settings.Level); err != nil {
+		logger.Warn("Invalid log level", "level", settings.Level, "error", err)
+		return
+	}
+	logger.Warn("Log level changed", "from", previous, "to", settings.Level)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveLogSettings(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	defaults := models.LogSettings{Level: "info", DebugSampleRate: 100}
+
+	// Nothing stored keeps the configured defaults
+	assert.Equal(t, &defaults, effectiveLogSettings(nil, defaults, now))
+
+	expiresAt := now.Add(30 * time.Minute)
+	stored, err := logSettingsValue(&models.LogSettings{Level: "debug", DebugSampleRate: 10, ExpiresAt: &expiresAt})
+	assert.NoError(t, err)
+	flag := &models.FeatureFlag{Name: FeatureFlagLogSettings, Value: stored}
+
+	settings := effectiveLogSettings(flag, defaults, now)
+	assert.Equal(t, "debug", settings.Level)
+	assert.Equal(t, 10, settings.DebugSampleRate)
+	assert.True(t, expiresAt.Equal(*settings.ExpiresAt))
+
+	// Expired settings fall back to the defaults
+	assert.Equal(t, &defaults, effectiveLogSettings(flag, defaults, expiresAt))
+
+	// So do settings that can't be applied
+	invalid := &models.FeatureFlag{Name: FeatureFlagLogSettings, Value: map[string]interface{}{"level": "loud"}}
+	assert.Equal(t, &defaults, effectiveLogSettings(invalid, defaults, now))
+}
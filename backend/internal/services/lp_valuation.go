@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LPValuation compares a liquidity position with having held the tokens it was entered
+// with. Values are in USD; ImpermanentLossPercent is positive when the pool lost value.
+type LPValuation struct {
+	EntryValueUSD float64 `json:"entry_value_usd"`
+	// HoldValueUSD is what the entry tokens would be worth now had they been held
+	HoldValueUSD float64 `json:"hold_value_usd"`
+	// PoolValueUSD is what the position would be worth now from price moves alone
+	PoolValueUSD           float64 `json:"pool_value_usd"`
+	ImpermanentLossUSD     float64 `json:"impermanent_loss_usd"`
+	ImpermanentLossPercent float64 `json:"impermanent_loss_percent"`
+	// FeesUSD is what the position earned beyond price moves, rewards included; nil
+	// without a current position value
+	FeesUSD *float64 `json:"fees_usd,omitempty"`
+	// FeeOffsetPercent is how much of the impermanent loss the fees made up
+	FeeOffsetPercent *float64 `json:"fee_offset_percent,omitempty"`
+	// NetVsHoldUSD is the position's value plus rewards less HoldValueUSD
+	NetVsHoldUSD *float64 `json:"net_vs_hold_usd,omitempty"`
+}
+
+// ValueLPPosition values an equal-weight constant-product position (Uniswap V2 style
+// pairs, balanced Balancer pools) entered for entryValueUSD at entryPrices. Prices are
+// keyed by token address and every entry token needs a current price. currentValueUSD
+// is the position's actual value and may be nil; rewardsUSD counts towards fees.
+func ValueLPPosition(entryValueUSD float64, entryPrices, currentPrices map[string]float64, currentValueUSD *float64, rewardsUSD float64) (*LPValuation, error) {
+	if entryValueUSD <= 0 {
+		return nil, fmt.Errorf("entry value must be positive")
+	}
+	if len(entryPrices) < 2 {
+		return nil, fmt.Errorf("a liquidity position needs entry prices for at least two tokens")
+	}
+
+	current := make(map[string]float64, len(currentPrices))
+	for token, price := range currentPrices {
+		current[strings.ToLower(token)] = price
+	}
+
+	// With equal weights w the pool's value scales by the weighted geometric mean of the
+	// price ratios while held tokens scale by their arithmetic mean
+	weight := 1 / float64(len(entryPrices))
+	poolRatio, holdRatio := 1.0, 0.0
+	for token, entry := range entryPrices {
+		price, ok := current[strings.ToLower(token)]
+		if !ok {
+			return nil, fmt.Errorf("no current price for %s", token)
+		}
+		if entry <= 0 || price <= 0 {
+			return nil, fmt.Errorf("prices for %s must be positive", token)
+		}
+		ratio := price / entry
+		poolRatio *= math.Pow(ratio, weight)
+		holdRatio += weight * ratio
+	}
+
+	valuation := &LPValuation{
+		EntryValueUSD: entryValueUSD,
+		HoldValueUSD:  entryValueUSD * holdRatio,
+		PoolValueUSD:  entryValueUSD * poolRatio,
+	}
+	valuation.ImpermanentLossUSD = valuation.HoldValueUSD - valuation.PoolValueUSD
+	valuation.ImpermanentLossPercent = (1 - poolRatio/holdRatio) * 100
+
+	if currentValueUSD != nil {
+		fees := *currentValueUSD + rewardsUSD - valuation.PoolValueUSD
+		net := *currentValueUSD + rewardsUSD - valuation.HoldValueUSD
+		valuation.FeesUSD = &fees
+		valuation.NetVsHoldUSD = &net
+		if valuation.ImpermanentLossUSD > 0 {
+			offset := fees / valuation.ImpermanentLossUSD * 100
+			valuation.FeeOffsetPercent = &offset
+		}
+	}
+
+	return valuation, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueLPPosition(t *testing.T) {
+	entry := map[string]float64{"0xweth": 2000, "0xusdc": 1}
+
+	t.Run("pair loses about 5.7% when one side doubles", func(t *testing.T) {
+		valuation, err := ValueLPPosition(10000, entry, map[string]float64{"0xWETH": 4000, "0xusdc": 1}, nil, 0)
+		require.NoError(t, err)
+
+		assert.InDelta(t, 15000, valuation.HoldValueUSD, 1e-6)
+		assert.InDelta(t, 14142.1356, valuation.PoolValueUSD, 1e-3)
+		assert.InDelta(t, 5.7191, valuation.ImpermanentLossPercent, 1e-3)
+		assert.InDelta(t, 857.8644, valuation.ImpermanentLossUSD, 1e-3)
+		assert.Nil(t, valuation.FeesUSD)
+		assert.Nil(t, valuation.FeeOffsetPercent)
+	})
+
+	t.Run("fees offset the loss", func(t *testing.T) {
+		current := 14500.0
+		valuation, err := ValueLPPosition(10000, entry, map[string]float64{"0xweth": 4000, "0xusdc": 1}, &current, 100)
+		require.NoError(t, err)
+
+		require.NotNil(t, valuation.FeesUSD)
+		assert.InDelta(t, 457.8644, *valuation.FeesUSD, 1e-3)
+		assert.InDelta(t, -400, *valuation.NetVsHoldUSD, 1e-6)
+		require.NotNil(t, valuation.FeeOffsetPercent)
+		assert.InDelta(t, 53.37, *valuation.FeeOffsetPercent, 1e-2)
+	})
+
+	t.Run("no loss when prices move together", func(t *testing.T) {
+		valuation, err := ValueLPPosition(10000, entry, map[string]float64{"0xweth": 3000, "0xusdc": 1.5}, nil, 0)
+		require.NoError(t, err)
+		assert.InDelta(t, 0, valuation.ImpermanentLossPercent, 1e-9)
+	})
+
+	t.Run("rejects missing prices", func(t *testing.T) {
+		_, err := ValueLPPosition(10000, entry, map[string]float64{"0xweth": 3000}, nil, 0)
+		assert.Error(t, err)
+
+		_, err = ValueLPPosition(10000, map[string]float64{"0xweth": 2000}, map[string]float64{"0xweth": 3000}, nil, 0)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+)
+
+const (
+	// marketListSize is how many entries each list of the market overview has
+	marketListSize = 10
+	// marketMinHolders is how many users must hold a token before its moves are shown,
+	// so the overview can't point at one user's holdings
+	marketMinHolders = 3
+	// marketMinTVLUSD keeps pools too small for their APY to be stable off the APY movers
+	marketMinTVLUSD = 1000000
+	// marketCacheTTL is how long the overview is served before being read again; the price
+	// refresh job behind it runs every 10 minutes
+	marketCacheTTL = 2 * time.Minute
+)
+
+// MarketService builds the market overview: trending coins, the biggest movers among
+// tokens held on the platform and the biggest APY changes. It needs no wallet or user,
+// so it can be shown before sign-in.
+type MarketService struct {
+	marketRepo repos.MarketRepository
+	clock      clock.Clock
+
+	mu       sync.Mutex
+	overview *models.MarketOverview
+}
+
+func NewMarketService(marketRepo repos.MarketRepository) *MarketService {
+	return &MarketService{
+		marketRepo: marketRepo,
+		clock:      clock.System,
+	}
+}
+
+// GetOverview returns the market overview, cached for marketCacheTTL
+func (s *MarketService) GetOverview(ctx context.Context) (*models.MarketOverview, error) {
+	now := s.clock.Now()
+	s.mu.Lock()
+	cached := s.overview
+	s.mu.Unlock()
+	if cached != nil && now.Sub(cached.GeneratedAt) < marketCacheTTL {
+		return cached, nil
+	}
+
+	overview := &models.MarketOverview{GeneratedAt: now}
+	var err error
+	if overview.Trending, err = s.marketRepo.GetTrendingCoins(ctx, marketListSize); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	// Stablecoins hardly move, so a few more are read to fill the lists without them
+	gainers, err := s.marketRepo.GetGainers(ctx, marketMinHolders, 2*marketListSize)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	losers, err := s.marketRepo.GetLosers(ctx, marketMinHolders, 2*marketListSize)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	overview.Gainers, overview.Losers = withoutStablecoins(gainers), withoutStablecoins(losers)
+	if overview.APYChanges, err = s.marketRepo.GetAPYChanges(ctx, marketMinTVLUSD, marketListSize); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	s.mu.Lock()
+	s.overview = overview
+	s.mu.Unlock()
+	return overview, nil
+}
+
+// withoutStablecoins drops USD stablecoins from movers, keeping at most marketListSize
+func withoutStablecoins(movers []*models.MarketMover) []*models.MarketMover {
+	kept := make([]*models.MarketMover, 0, marketListSize)
+	for _, mover := range movers {
+		if len(kept) == marketListSize {
+			break
+		}
+		if !external.IsUSDAsset(mover.Symbol) {
+			kept = append(kept, mover)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryMarketRepo struct {
+	gainers, losers []*models.MarketMover
+	reads           int
+}
+
+func (r *memoryMarketRepo) GetTrendingCoins(ctx context.Context, limit int) ([]*models.TrendingCoin, error) {
+	r.reads++
+	return []*models.TrendingCoin{{Rank: 1, CoinGeckoID: "pendle", Symbol: "PENDLE", Name: "Pendle"}}, nil
+}
+
+func (r *memoryMarketRepo) GetGainers(ctx context.Context, minHolders, limit int) ([]*models.MarketMover, error) {
+	return r.gainers, nil
+}
+
+func (r *memoryMarketRepo) GetLosers(ctx context.Context, minHolders, limit int) ([]*models.MarketMover, error) {
+	return r.losers, nil
+}
+
+func (r *memoryMarketRepo) GetAPYChanges(ctx context.Context, minTVLUSD float64, limit int) ([]*models.APYChange, error) {
+	return []*models.APYChange{}, nil
+}
+
+func TestMarketServiceGetOverview(t *testing.T) {
+	repo := &memoryMarketRepo{
+		gainers: []*models.MarketMover{
+			{Symbol: "ARB", PriceChange24h: 9.2},
+			{Symbol: "USDC", PriceChange24h: 0.02},
+		},
+		losers: []*models.MarketMover{{Symbol: "DAI", PriceChange24h: -0.01}},
+	}
+	for i := 0; i < 2*marketListSize; i++ {
+		repo.gainers = append(repo.gainers, &models.MarketMover{Symbol: "T" + string(rune('A'+i))})
+	}
+	service := NewMarketService(repo)
+	clk := clock.NewFake(time.Date(2026, 3, 18, 12, 0, 0, 0, time.UTC))
+	service.clock = clk
+	ctx := context.Background()
+
+	overview, err := service.GetOverview(ctx)
+	require.NoError(t, err)
+	require.Len(t, overview.Gainers, marketListSize)
+	assert.Equal(t, "ARB", overview.Gainers[0].Symbol)
+	assert.Equal(t, "TA", overview.Gainers[1].Symbol, "stablecoins are left out of the movers")
+	assert.Empty(t, overview.Losers)
+	assert.Len(t, overview.Trending, 1)
+
+	_, err = service.GetOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.reads, "the overview is cached")
+
+	clk.Advance(marketCacheTTL)
+	_, err = service.GetOverview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.reads)
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+)
+
+const (
+	// maxMetricExpression is the longest expression a custom metric can have, in bytes
+	maxMetricExpression = 500
+	// maxMetricNodes keeps expressions cheap to evaluate
+	maxMetricNodes = 64
+)
+
+// metricVariables are the numbers custom metric expressions are computed over, in the
+// order they're documented
+var metricVariables = []models.MetricVariable{
+	{Name: "total_value_usd", Description: "Value of the tokens in your wallets and your active yield positions"},
+	{Name: "wallet_value_usd", Description: "Value of the tokens in your wallets"},
+	{Name: "positions_value_usd", Description: "Value of your active yield positions"},
+	{Name: "stablecoin_value_usd", Description: "Value of your stablecoins and positions in stablecoin pools"},
+	{Name: "apy_weighted_usd", Description: "Yearly yield of your positions at their pools' APY, each position's value times its APY"},
+	{Name: "position_count", Description: "Number of active yield positions"},
+	{Name: "token_count", Description: "Number of different tokens in your wallets"},
+}
+
+// metricFunctions are the functions expressions can call, by name, with the kinds of
+// their arguments: 's' for a quoted string, 'n' for a number
+var metricFunctions = map[string]string{
+	"value": "s",
+	"min":   "nn",
+	"max":   "nn",
+	"abs":   "n",
+}
+
+// metricFunctionDocs documents metricFunctions, in order
+var metricFunctionDocs = []models.MetricVariable{
+	{Name: `value("SYMBOL")`, Description: "Value of the token with the symbol in your wallets and of positions in pools with the symbol"},
+	{Name: "min(a, b)", Description: "The smaller of two numbers"},
+	{Name: "max(a, b)", Description: "The larger of two numbers"},
+	{Name: "abs(a)", Description: "A number without its sign"},
+}
+
+// metricExpr is a parsed custom metric expression: arithmetic (+ - * / and parentheses)
+// over numbers, the metricVariables and calls of the metricFunctions, like
+//
+//	stablecoin_value_usd / total_value_usd * 100
+//	apy_weighted_usd / max(positions_value_usd, 1) * 100
+//	value("ETH") + value("WETH")
+type metricExpr struct {
+	root metricNode
+}
+
+type metricNode interface{}
+
+type (
+	metricNumber float64
+	metricString string
+	metricVar    string
+	metricNeg    struct{ x metricNode }
+	metricBinary struct {
+		op   byte
+		x, y metricNode
+	}
+	metricCall struct {
+		name string
+		args []metricNode
+	}
+)
+
+// metricEnv holds the values a user's metrics are evaluated with
+type metricEnv struct {
+	vars map[string]float64
+	// values are the holdings' values by upper-case symbol
+	values map[string]float64
+}
+
+// parseMetricExpr parses and checks an expression, returning a bad request describing
+// the first problem found
+func parseMetricExpr(src string) (*metricExpr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errors.BadRequest("Expression is required")
+	}
+	if len(src) > maxMetricExpression {
+		return nil, errors.BadRequest(fmt.Sprintf("Expression must be at most %d characters", maxMetricExpression))
+	}
+
+	tokens, err := lexMetricExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &metricParser{tokens: tokens}
+	root, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != metricTokenEOF {
+		return nil, metricSyntaxError(tok, "unexpected "+tok.describe())
+	}
+	if p.nodes > maxMetricNodes {
+		return nil, errors.BadRequest(fmt.Sprintf("Expression has more than %d terms", maxMetricNodes))
+	}
+	return &metricExpr{root: root}, nil
+}
+
+// eval computes the expression. Dividing by zero is an error rather than an infinite
+// value, so widgets show the metric can't be computed yet.
+func (e *metricExpr) eval(env *metricEnv) (float64, error) {
+	return evalMetricNode(e.root, env)
+}
+
+func evalMetricNode(node metricNode, env *metricEnv) (float64, error) {
+	switch n := node.(type) {
+	case metricNumber:
+		return float64(n), nil
+	case metricVar:
+		return env.vars[string(n)], nil
+	case metricNeg:
+		x, err := evalMetricNode(n.x, env)
+		return -x, err
+	case metricBinary:
+		x, err := evalMetricNode(n.x, env)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evalMetricNode(n.y, env)
+		if err != nil {
+			return 0, err
+		}
+		switch n.op {
+		case '+':
+			return x + y, nil
+		case '-':
+			return x - y, nil
+		case '*':
+			return x * y, nil
+		default:
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		}
+	case metricCall:
+		if n.name == "value" {
+			return env.values[strings.ToUpper(string(n.args[0].(metricString)))], nil
+		}
+		args := make([]float64, len(n.args))
+		for i, arg := range n.args {
+			v, err := evalMetricNode(arg, env)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = v
+		}
+		switch n.name {
+		case "min":
+			return math.Min(args[0], args[1]), nil
+		case "max":
+			return math.Max(args[0], args[1]), nil
+		default:
+			return math.Abs(args[0]), nil
+		}
+	}
+	return 0, fmt.Errorf("unexpected expression node %T", node)
+}
+
+type metricTokenKind int
+
+const (
+	metricTokenEOF metricTokenKind = iota
+	metricTokenNumber
+	metricTokenString
+	metricTokenIdent
+	metricTokenOp
+)
+
+type metricToken struct {
+	kind metricTokenKind
+	text string
+	pos  int
+}
+
+func (t metricToken) describe() string {
+	if t.kind == metricTokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func metricSyntaxError(tok metricToken, msg string) error {
+	return errors.BadRequest(fmt.Sprintf("Invalid expression at character %d: %s", tok.pos+1, msg))
+}
+
+func lexMetricExpr(src string) ([]metricToken, error) {
+	var tokens []metricToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, metricToken{kind: metricTokenNumber, text: src[start:i], pos: start})
+		case isMetricIdentStart(c):
+			start := i
+			for i < len(src) && (isMetricIdentStart(src[i]) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, metricToken{kind: metricTokenIdent, text: src[start:i], pos: start})
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, metricSyntaxError(metricToken{pos: i}, "unterminated string")
+			}
+			tokens = append(tokens, metricToken{kind: metricTokenString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case strings.IndexByte("+-*/(),", c) >= 0:
+			tokens = append(tokens, metricToken{kind: metricTokenOp, text: string(c), pos: i})
+			i++
+		default:
+			return nil, metricSyntaxError(metricToken{pos: i}, fmt.Sprintf("unexpected character %q", c))
+		}
+	}
+	return append(tokens, metricToken{kind: metricTokenEOF, pos: len(src)}), nil
+}
+
+func metricArity(name, kinds string) string {
+	if len(kinds) == 1 {
+		return name + " takes 1 argument"
+	}
+	return fmt.Sprintf("%s takes %d arguments", name, len(kinds))
+}
+
+func isMetricIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// metricParser parses by recursive descent:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | variable | function "(" [ arg { "," arg } ] ")" | "(" expr ")"
+//	arg     = string | expr
+type metricParser struct {
+	tokens []metricToken
+	next   int
+	nodes  int
+	depth  int
+}
+
+func (p *metricParser) peek() metricToken {
+	return p.tokens[p.next]
+}
+
+func (p *metricParser) take() metricToken {
+	tok := p.tokens[p.next]
+	if tok.kind != metricTokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *metricParser) isOp(ops string) bool {
+	tok := p.peek()
+	return tok.kind == metricTokenOp && strings.Contains(ops, tok.text)
+}
+
+func (p *metricParser) expect(op string) error {
+	if tok := p.take(); tok.kind != metricTokenOp || tok.text != op {
+		return metricSyntaxError(tok, fmt.Sprintf("expected %q, found %s", op, tok.describe()))
+	}
+	return nil
+}
+
+func (p *metricParser) node(n metricNode) metricNode {
+	p.nodes++
+	return n
+}
+
+func (p *metricParser) expr() (metricNode, error) {
+	// Parentheses nest through here, so this bounds the recursion
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxMetricNodes {
+		return nil, metricSyntaxError(p.peek(), "nested too deeply")
+	}
+
+	x, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+-") {
+		op := p.take().text[0]
+		y, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		x = p.node(metricBinary{op: op, x: x, y: y})
+	}
+	return x, nil
+}
+
+func (p *metricParser) term() (metricNode, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*/") {
+		op := p.take().text[0]
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = p.node(metricBinary{op: op, x: x, y: y})
+	}
+	return x, nil
+}
+
+func (p *metricParser) unary() (metricNode, error) {
+	if p.isOp("-") {
+		p.take()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return p.node(metricNeg{x: x}), nil
+	}
+	return p.primary()
+}
+
+func (p *metricParser) primary() (metricNode, error) {
+	tok := p.take()
+	switch tok.kind {
+	case metricTokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, metricSyntaxError(tok, "invalid number "+tok.describe())
+		}
+		return p.node(metricNumber(v)), nil
+	case metricTokenIdent:
+		if p.isOp("(") {
+			return p.call(tok)
+		}
+		for _, v := range metricVariables {
+			if v.Name == tok.text {
+				return p.node(metricVar(tok.text)), nil
+			}
+		}
+		return nil, metricSyntaxError(tok, "unknown variable "+tok.describe())
+	case metricTokenString:
+		return nil, metricSyntaxError(tok, "strings can only be function arguments")
+	case metricTokenOp:
+		if tok.text == "(" {
+			x, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, metricSyntaxError(tok, "unexpected "+tok.describe())
+}
+
+func (p *metricParser) call(name metricToken) (metricNode, error) {
+	kinds, ok := metricFunctions[name.text]
+	if !ok {
+		return nil, metricSyntaxError(name, "unknown function "+name.describe())
+	}
+	p.take() // (
+
+	var args []metricNode
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if len(args) == len(kinds) {
+			return nil, metricSyntaxError(p.peek(), metricArity(name.text, kinds))
+		}
+		if kinds[len(args)] == 's' {
+			tok := p.take()
+			if tok.kind != metricTokenString {
+				return nil, metricSyntaxError(tok, fmt.Sprintf("%s takes a quoted string", name.text))
+			}
+			args = append(args, p.node(metricString(tok.text)))
+			continue
+		}
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) != len(kinds) {
+		return nil, metricSyntaxError(p.peek(), metricArity(name.text, kinds))
+	}
+	p.take() // )
+	return p.node(metricCall{name: name.text, args: args}), nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricExprEval(t *testing.T) {
+	env := &metricEnv{
+		vars: map[string]float64{
+			"total_value_usd":      1000,
+			"stablecoin_value_usd": 250,
+			"positions_value_usd":  0,
+		},
+		values: map[string]float64{"ETH": 300, "WETH": 50},
+	}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"stablecoin_value_usd / total_value_usd * 100", 25},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"-2 * -3", 6},
+		{`value("eth") + value("WETH")`, 350},
+		{`value("BTC")`, 0},
+		{"max(positions_value_usd, 1)", 1},
+		{"min(total_value_usd, 2.5)", 2.5},
+		{"abs(stablecoin_value_usd - total_value_usd)", 750},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := parseMetricExpr(tt.expr)
+			require.NoError(t, err)
+			got, err := expr.eval(env)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+
+	expr, err := parseMetricExpr("total_value_usd / positions_value_usd")
+	require.NoError(t, err)
+	_, err = expr.eval(env)
+	assert.EqualError(t, err, "division by zero")
+}
+
+func TestParseMetricExprRejects(t *testing.T) {
+	tests := []struct {
+		expr string
+		msg  string
+	}{
+		{"", "Expression is required"},
+		{"1 +", "at character 4: unexpected end of expression"},
+		{"portfolio_value", `unknown variable "portfolio_value"`},
+		{"sqrt(4)", `unknown function "sqrt"`},
+		{"min(1)", "min takes 2 arguments"},
+		{"abs(1, 2)", "abs takes 1 argument"},
+		{"value(ETH)", "value takes a quoted string"},
+		{`"ETH" + 1`, "strings can only be function arguments"},
+		{"(1 + 2", `expected ")"`},
+		{"1 2", `unexpected "2"`},
+		{"2 ^ 3", "unexpected character '^'"},
+		{`value("ETH)`, "unterminated string"},
+		{"1..2", `invalid number "1..2"`},
+		{strings.Repeat("1+", 40) + "1", "more than 64 terms"},
+		{strings.Repeat("1", 501), "at most 500 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseMetricExpr(tt.expr)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
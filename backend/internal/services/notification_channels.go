@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/netguard"
+	"github.com/google/uuid"
+)
+
+// NotificationRecipient is who a notification goes to on one channel
+type NotificationRecipient struct {
+	UserID uuid.UUID
+	// Address is the channel's destination: an email address, URL or chat ID
+	Address string
+}
+
+// NotificationChannel delivers alert notifications over one medium. The dispatcher
+// resolves each alert's channels, applies mutes and quiet hours, and logs and retries
+// deliveries the same way whichever channel they go through, so adding a channel is
+// a matter of implementing this and registering it.
+type NotificationChannel interface {
+	// Name identifies the channel in delivery logs and the notification queue
+	Name() string
+	// Address returns where the alert is delivered on this channel, or "" if the alert
+	// doesn't use it. user is the alert's owner.
+	Address(alert *models.Alert, user *models.User) string
+	Send(ctx context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error
+	// SupportsRichContent reports whether the channel shows multi-line detail; others
+	// get a one-line summary as the message text
+	SupportsRichContent() bool
+}
+
+// NotificationChannelRegistry holds the channels a dispatcher delivers through
+type NotificationChannelRegistry struct {
+	mu       sync.RWMutex
+	channels map[string]NotificationChannel
+	order    []string
+}
+
+func NewNotificationChannelRegistry() *NotificationChannelRegistry {
+	return &NotificationChannelRegistry{channels: make(map[string]NotificationChannel)}
+}
+
+// Register adds a channel, refusing a second one with the same name
+func (r *NotificationChannelRegistry) Register(channel NotificationChannel) error {
+	name := channel.Name()
+	if name == "" {
+		return fmt.Errorf("notification channel has no name")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.channels[name]; exists {
+		return fmt.Errorf("notification channel %s already registered", name)
+	}
+	r.channels[name] = channel
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get returns the channel registered under a name
+func (r *NotificationChannelRegistry) Get(name string) (NotificationChannel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channel, ok := r.channels[name]
+	return channel, ok
+}
+
+// Channels returns the registered channels in registration order
+func (r *NotificationChannelRegistry) Channels() []NotificationChannel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channels := make([]NotificationChannel, 0, len(r.order))
+	for _, name := range r.order {
+		channels = append(channels, r.channels[name])
+	}
+	return channels
+}
+
+// DefaultNotificationChannels returns a registry with the email and webhook channels.
+// A nil emailSender logs emails instead of sending them. Webhooks are only posted to
+// public addresses.
+func DefaultNotificationChannels(emailSender EmailSender) *NotificationChannelRegistry {
+	if emailSender == nil {
+		emailSender = LogEmailSender{}
+	}
+	client := netguard.Policy{}.Client(webhookTimeout)
+
+	registry := NewNotificationChannelRegistry()
+	_ = registry.Register(NewEmailChannel(emailSender))
+	_ = registry.Register(NewWebhookChannel(client))
+	return registry
+}
+
+// emailChannel sends to the alert owner's email address
+type emailChannel struct {
+	sender EmailSender
+}
+
+func NewEmailChannel(sender EmailSender) NotificationChannel {
+	return &emailChannel{sender: sender}
+}
+
+func (c *emailChannel) Name() string { return models.NotificationChannelEmail }
+
+func (c *emailChannel) Address(alert *models.Alert, user *models.User) string {
+	if !alert.Notification.Email || user == nil || user.Email == nil {
+		return ""
+	}
+	return *user.Email
+}
+
+func (c *emailChannel) Send(ctx context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error {
+	return c.sender.SendAlertEmail(ctx, to.Address, message)
+}
+
+func (c *emailChannel) SupportsRichContent() bool { return true }
+
+// webhookChannel posts the message as JSON to the alert's webhook URL
+type webhookChannel struct {
+	client   *http.Client
+	verifier WebhookVerifier
+}
+
+func NewWebhookChannel(client *http.Client) NotificationChannel {
+	return &webhookChannel{client: client}
+}
+
+// NewVerifiedWebhookChannel only posts to URLs the alert's owner has verified. client
+// should refuse private addresses, as from netguard.Policy.Client.
+func NewVerifiedWebhookChannel(client *http.Client, verifier WebhookVerifier) NotificationChannel {
+	return &webhookChannel{client: client, verifier: verifier}
+}
+
+func (c *webhookChannel) Name() string { return models.NotificationChannelWebhook }
+
+func (c *webhookChannel) Address(alert *models.Alert, _ *models.User) string {
+	return alert.Notification.Webhook
+}
+
+func (c *webhookChannel) Send(ctx context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error {
+	if c.verifier != nil {
+		verified, err := c.verifier.IsVerified(ctx, to.UserID, to.Address)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return fmt.Errorf("webhook URL has not been verified")
+		}
+	}
+	// Receivers can drop retried deliveries by the idempotency key
+	return postJSON(ctx, c.client, to.Address, message, map[string]string{"Idempotency-Key": message.DeliveryID.String()})
+}
+
+func (c *webhookChannel) SupportsRichContent() bool { return false }
+
+// discordWebhookPrefixes are the URLs Discord issues channel webhooks under
+var discordWebhookPrefixes = []string{
+	"https://discord.com/api/webhooks/",
+	"https://discordapp.com/api/webhooks/",
+}
+
+func isDiscordWebhookURL(url string) bool {
+	for _, prefix := range discordWebhookPrefixes {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// discordChannel posts the message text to a Discord channel webhook
+type discordChannel struct {
+	client *http.Client
+}
+
+func NewDiscordChannel(client *http.Client) NotificationChannel {
+	return &discordChannel{client: client}
+}
+
+func (c *discordChannel) Name() string { return models.NotificationChannelDiscord }
+
+func (c *discordChannel) Address(alert *models.Alert, _ *models.User) string {
+	return alert.Notification.Discord
+}
+
+func (c *discordChannel) Send(ctx context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error {
+	if !isDiscordWebhookURL(to.Address) {
+		return fmt.Errorf("not a Discord webhook URL")
+	}
+	// Message templates can bring in token symbols and labels, which mustn't ping anyone
+	return postJSON(ctx, c.client, to.Address, map[string]interface{}{
+		"content":          message.Text,
+		"allowed_mentions": map[string][]string{"parse": {}},
+	}, nil)
+}
+
+func (c *discordChannel) SupportsRichContent() bool { return true }
+
+// telegramChannel posts the message text to a chat through a Telegram bot
+type telegramChannel struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewTelegramChannel sends through the bot the token belongs to; the bot must be a
+// member of the chats alerts name
+func NewTelegramChannel(client *http.Client, botToken string) NotificationChannel {
+	return &telegramChannel{client: client, baseURL: "https://api.telegram.org/bot" + botToken}
+}
+
+func (c *telegramChannel) Name() string { return models.NotificationChannelTelegram }
+
+func (c *telegramChannel) Address(alert *models.Alert, _ *models.User) string {
+	return alert.Notification.Telegram
+}
+
+func (c *telegramChannel) Send(ctx context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error {
+	return postJSON(ctx, c.client, c.baseURL+"/sendMessage", map[string]string{
+		"chat_id": to.Address,
+		"text":    message.Text,
+	}, nil)
+}
+
+func (c *telegramChannel) SupportsRichContent() bool { return true }
+
+// inAppChannel pushes the message to the owner's connected clients as a realtime event
+type inAppChannel struct {
+	publisher events.Publisher
+}
+
+func NewInAppChannel(publisher events.Publisher) NotificationChannel {
+	return &inAppChannel{publisher: publisher}
+}
+
+func (c *inAppChannel) Name() string { return models.NotificationChannelInApp }
+
+func (c *inAppChannel) Address(alert *models.Alert, _ *models.User) string {
+	if !alert.Notification.InApp {
+		return ""
+	}
+	return alert.UserID.String()
+}
+
+func (c *inAppChannel) Send(ctx context.Context, to NotificationRecipient, message models.AlertNotificationMessage) error {
+	event, err := events.NewEvent(events.TypeAlertTriggered, to.UserID, message)
+	if err != nil {
+		return err
+	}
+	return c.publisher.Publish(ctx, event)
+}
+
+func (c *inAppChannel) SupportsRichContent() bool { return false }
+
+// postJSON posts body as JSON, treating any non-2xx status as a failure. Only the
+// start of the response is read, so a receiver can't hold the sender up streaming one.
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}, headers map[string]string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification payload: %w", err)
+	}
+	return postPayload(ctx, client, url, payload, headers)
+}
+
+// postPayload posts an encoded JSON body, for callers that sign it
+func postPayload(ctx context.Context, client *http.Client, url string, payload []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notificationText renders a message for people to read. Rich channels get each
+// triggered value on its own line after the headline; the rest get the headline alone.
+func notificationText(message models.AlertNotificationMessage, rich bool) string {
+	headline := "Alert triggered: " + strings.ReplaceAll(message.Type, "_", " ")
+	if message.TriggeredValue["event"] == models.AlertTargetEventGone {
+		headline = "Alert expired: " + strings.ReplaceAll(message.Type, "_", " ")
+	}
+	if message.Target.Identifier != "" {
+		headline += " on " + message.Target.Identifier
+	}
+	if reason, ok := message.TriggeredValue["reason"].(string); ok && message.TriggeredValue["event"] == models.AlertTargetEventGone {
+		headline += ", " + strings.ReplaceAll(reason, "_", " ")
+	}
+	if !rich || len(message.TriggeredValue) == 0 {
+		return headline
+	}
+
+	keys := make([]string, 0, len(message.TriggeredValue))
+	for k := range message.TriggeredValue {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := []string{headline}
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %v", k, message.TriggeredValue[k]))
+	}
+	return strings.Join(lines, "\n")
+}
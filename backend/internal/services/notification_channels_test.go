@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event events.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestNotificationChannelRegistry(t *testing.T) {
+	registry := DefaultNotificationChannels(nil)
+	publisher := &recordingPublisher{}
+	require.NoError(t, registry.Register(NewInAppChannel(publisher)))
+	assert.Error(t, registry.Register(NewWebhookChannel(http.DefaultClient)))
+
+	var names []string
+	for _, channel := range registry.Channels() {
+		names = append(names, channel.Name())
+	}
+	assert.Equal(t, []string{models.NotificationChannelEmail, models.NotificationChannelWebhook, models.NotificationChannelInApp}, names)
+
+	email := "owner@example.com"
+	alert := &models.Alert{UserID: uuid.New(), Notification: models.AlertNotification{Email: true, InApp: true}}
+	user := &models.User{Email: &email}
+	addresses := map[string]string{}
+	for _, channel := range registry.Channels() {
+		addresses[channel.Name()] = channel.Address(alert, user)
+	}
+	assert.Equal(t, map[string]string{
+		models.NotificationChannelEmail:   email,
+		models.NotificationChannelWebhook: "",
+		models.NotificationChannelInApp:   alert.UserID.String(),
+	}, addresses)
+
+	inApp, ok := registry.Get(models.NotificationChannelInApp)
+	require.True(t, ok)
+	require.NoError(t, inApp.Send(context.Background(), NotificationRecipient{UserID: alert.UserID}, models.AlertNotificationMessage{Type: models.AlertTypePriceAbove}))
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, events.TypeAlertTriggered, publisher.events[0].Type)
+	assert.Equal(t, alert.UserID, publisher.events[0].UserID)
+}
+
+func TestWebhookChannelSend(t *testing.T) {
+	var got models.AlertNotificationMessage
+	var idempotencyKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey = r.Header.Get("Idempotency-Key")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	message := models.AlertNotificationMessage{AlertID: uuid.New(), DeliveryID: uuid.New(), Type: models.AlertTypePriceBelow}
+	channel := NewWebhookChannel(server.Client())
+	require.NoError(t, channel.Send(context.Background(), NotificationRecipient{Address: server.URL}, message))
+	assert.Equal(t, message.DeliveryID.String(), idempotencyKey)
+	assert.Equal(t, message.AlertID, got.AlertID)
+}
+
+func TestTelegramChannelSend(t *testing.T) {
+	var path string
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	channel := &telegramChannel{client: server.Client(), baseURL: server.URL + "/botTOKEN"}
+	require.NoError(t, channel.Send(context.Background(), NotificationRecipient{Address: "-100123"}, models.AlertNotificationMessage{Text: "Alert triggered"}))
+	assert.Equal(t, "/botTOKEN/sendMessage", path)
+	assert.Equal(t, map[string]string{"chat_id": "-100123", "text": "Alert triggered"}, body)
+}
+
+func TestDiscordChannelRejectsOtherURLs(t *testing.T) {
+	channel := NewDiscordChannel(http.DefaultClient)
+	err := channel.Send(context.Background(), NotificationRecipient{Address: "http://169.254.169.254/latest"}, models.AlertNotificationMessage{})
+	assert.Error(t, err)
+}
+
+func TestNotificationText(t *testing.T) {
+	message := models.AlertNotificationMessage{
+		Type:           models.AlertTypePriceAbove,
+		Target:         models.AlertTarget{Identifier: "ETH"},
+		TriggeredValue: map[string]interface{}{"targetPrice": 3000, "currentPrice": 3100.5},
+	}
+	assert.Equal(t, "Alert triggered: price above on ETH", notificationText(message, false))
+	assert.Equal(t, "Alert triggered: price above on ETH\ncurrentPrice: 3100.5\ntargetPrice: 3000", notificationText(message, true))
+}
+
+func TestNotificationTextForExpiredAlert(t *testing.T) {
+	message := models.AlertNotificationMessage{
+		Type:           models.AlertTypeAPRChange,
+		Target:         models.AlertTarget{Type: "pool", Identifier: "pool-1"},
+		TriggeredValue: map[string]interface{}{"event": models.AlertTargetEventGone, "reason": models.AlertExpiredPoolDeactivated},
+	}
+	assert.Equal(t, "Alert expired: apr change on pool-1, pool deactivated", notificationText(message, false))
+}
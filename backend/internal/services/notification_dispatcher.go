@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	queuedNotificationBatchSize   = 100
+	queuedNotificationMaxAttempts = 5
+	queuedNotificationRetryDelay  = 5 * time.Minute
+)
+
+// EmailSender delivers alert notifications by email
+type EmailSender interface {
+	SendAlertEmail(ctx context.Context, to string, message models.AlertNotificationMessage) error
+}
+
+// LogEmailSender logs emails instead of sending them, for environments without a mail provider
+type LogEmailSender struct{}
+
+func (LogEmailSender) SendAlertEmail(ctx context.Context, to string, message models.AlertNotificationMessage) error {
+	logger.Info("Alert email", "to", to, "alertID", message.AlertID, "type", message.Type)
+	return nil
+}
+
+// DeliveryError reports the channels a notification couldn't be delivered to. The
+// channels that did go out are recorded, so a retry only resends the failed ones.
+type DeliveryError struct {
+	Failures []string
+}
+
+func (e *DeliveryError) Error() string {
+	return "notification delivery failed: " + strings.Join(e.Failures, "; ")
+}
+
+// NotificationDispatcher delivers triggered alerts to their channels, honouring
+// per-alert mutes and the owner's quiet hours
+type NotificationDispatcher interface {
+	Dispatch(ctx context.Context, alert *models.Alert, history *models.AlertHistory) error
+	DispatchUrgent(ctx context.Context, alert *models.Alert, history *models.AlertHistory) error
+	DeliverQueued(ctx context.Context) (int, error)
+}
+
+type notificationDispatcher struct {
+	notificationRepo repos.NotificationRepository
+	userRepo         repos.UserRepository
+	channels         *NotificationChannelRegistry
+	// templates fills in the token and wallet variables of alerts' message templates
+	templates *NotificationTemplateService
+	clock     clock.Clock
+}
+
+// NewNotificationDispatcher creates a dispatcher delivering by email and webhook
+func NewNotificationDispatcher(notificationRepo repos.NotificationRepository, userRepo repos.UserRepository, emailSender EmailSender) NotificationDispatcher {
+	return NewNotificationDispatcherWithChannels(notificationRepo, userRepo, DefaultNotificationChannels(emailSender), nil)
+}
+
+// NewNotificationDispatcherWithChannels creates a dispatcher delivering through the
+// registered channels. Without templates, message templates only get the variables
+// alerts carry themselves.
+func NewNotificationDispatcherWithChannels(notificationRepo repos.NotificationRepository, userRepo repos.UserRepository, channels *NotificationChannelRegistry, templates *NotificationTemplateService) NotificationDispatcher {
+	return &notificationDispatcher{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		channels:         channels,
+		templates:        templates,
+		clock:            clock.System,
+	}
+}
+
+type delivery struct {
+	channel     string
+	destination string
+}
+
+func (d *notificationDispatcher) Dispatch(ctx context.Context, alert *models.Alert, history *models.AlertHistory) error {
+	return d.dispatch(ctx, alert, history, false)
+}
+
+// DispatchUrgent delivers immediately even during quiet hours, for notifications that
+// can't wait until morning such as imminent liquidations. Mutes still apply.
+func (d *notificationDispatcher) DispatchUrgent(ctx context.Context, alert *models.Alert, history *models.AlertHistory) error {
+	return d.dispatch(ctx, alert, history, true)
+}
+
+func (d *notificationDispatcher) dispatch(ctx context.Context, alert *models.Alert, history *models.AlertHistory, urgent bool) error {
+	now := d.clock.Now()
+
+	deliveries, err := d.deliveries(ctx, alert)
+	if err != nil {
+		return err
+	}
+
+	message := models.AlertNotificationMessage{
+		AlertID:        alert.ID,
+		HistoryID:      history.ID,
+		Type:           alert.Type,
+		Target:         alert.Target,
+		TriggeredAt:    history.TriggeredAt,
+		TriggeredValue: history.TriggeredValue,
+	}
+	// An expiry isn't a trigger, so the alert's template doesn't describe it
+	if alert.Notification.Template != "" && history.TriggeredValue["event"] != models.AlertTargetEventGone {
+		// Rendered once, so queued copies keep the text the trigger had
+		message.Text = d.renderTemplate(ctx, alert, message)
+	}
+
+	if alert.MutedUntil != nil && now.Before(*alert.MutedUntil) {
+		logger.Debug("Alert muted, skipping notification", "alertID", alert.ID, "mutedUntil", alert.MutedUntil)
+		reason := "alert muted"
+		for _, dl := range deliveries {
+			d.recordDelivery(ctx, alert.UserID, dl.channel, message, models.DeliveryStatusDropped, &reason)
+		}
+		return d.notificationRepo.MarkHistoryNotified(ctx, history.ID, history.TriggeredAt, false, &reason)
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	settings, err := d.notificationRepo.GetSettings(ctx, alert.UserID)
+	if err != nil {
+		return err
+	}
+
+	quietUntil, quiet, err := quietHoursEnd(settings, now)
+	if err != nil {
+		// Bad settings shouldn't swallow alerts; deliver immediately instead
+		logger.Warn("Invalid quiet hours settings", "userID", alert.UserID, "error", err)
+	}
+
+	if quiet && !urgent {
+		if settings.QuietHoursAction == models.QuietHoursActionDrop {
+			reason := "dropped during quiet hours"
+			for _, dl := range deliveries {
+				d.recordDelivery(ctx, alert.UserID, dl.channel, message, models.DeliveryStatusDropped, &reason)
+			}
+			return d.notificationRepo.MarkHistoryNotified(ctx, history.ID, history.TriggeredAt, false, &reason)
+		}
+
+		for _, dl := range deliveries {
+			payload := message
+			payload.DeliveryID = deliveryID(history.ID, dl.channel)
+			err := d.notificationRepo.Enqueue(ctx, &models.QueuedNotification{
+				AlertID:      alert.ID,
+				HistoryID:    history.ID,
+				UserID:       alert.UserID,
+				Channel:      dl.channel,
+				Destination:  dl.destination,
+				Payload:      payload,
+				DeliverAfter: quietUntil,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		logger.Debug("Notification queued for quiet hours", "alertID", alert.ID, "deliverAfter", quietUntil)
+		return nil
+	}
+
+	var failures []string
+	for _, dl := range deliveries {
+		message.DeliveryID = deliveryID(history.ID, dl.channel)
+		// A retried dispatch skips the channels an earlier attempt already reached
+		sent, err := d.notificationRepo.HasSentDelivery(ctx, message.DeliveryID)
+		if err != nil {
+			return err
+		}
+		if sent {
+			continue
+		}
+
+		if err := d.send(ctx, alert.UserID, dl.channel, dl.destination, message); err != nil {
+			logger.Error("Failed to send notification", "alertID", alert.ID, "channel", dl.channel, "error", err)
+			failures = append(failures, fmt.Sprintf("%s: %v", dl.channel, err))
+			errMsg := err.Error()
+			d.recordDelivery(ctx, alert.UserID, dl.channel, message, models.DeliveryStatusFailed, &errMsg)
+			continue
+		}
+		d.recordDelivery(ctx, alert.UserID, dl.channel, message, models.DeliveryStatusSent, nil)
+	}
+
+	if err := d.markHistory(ctx, history.ID, history.TriggeredAt, failures); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return &DeliveryError{Failures: failures}
+	}
+	return nil
+}
+
+// DeliverQueued sends queued notifications whose quiet hours have ended and returns how many were sent
+func (d *notificationDispatcher) DeliverQueued(ctx context.Context) (int, error) {
+	queued, err := d.notificationRepo.GetDue(ctx, queuedNotificationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, n := range queued {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		// Rows queued before delivery IDs existed get theirs here
+		n.Payload.DeliveryID = deliveryID(n.HistoryID, n.Channel)
+		alreadySent, err := d.notificationRepo.HasSentDelivery(ctx, n.Payload.DeliveryID)
+		if err != nil {
+			return sent, err
+		}
+		if alreadySent {
+			// Queued twice by a retried dispatch; the first copy already went out
+			if err := d.notificationRepo.DeleteQueued(ctx, n.ID); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		sendErr := d.send(ctx, n.UserID, n.Channel, n.Destination, n.Payload)
+		if sendErr == nil {
+			d.recordDelivery(ctx, n.UserID, n.Channel, n.Payload, models.DeliveryStatusSent, nil)
+			if err := d.notificationRepo.DeleteQueued(ctx, n.ID); err != nil {
+				return sent, err
+			}
+			if err := d.notificationRepo.MarkHistoryNotified(ctx, n.HistoryID, n.Payload.TriggeredAt, true, nil); err != nil {
+				return sent, err
+			}
+			sent++
+			continue
+		}
+
+		logger.Error("Failed to send queued notification",
+			"id", n.ID, "alertID", n.AlertID, "channel", n.Channel, "attempts", n.Attempts+1, "error", sendErr)
+		errMsg := sendErr.Error()
+		d.recordDelivery(ctx, n.UserID, n.Channel, n.Payload, models.DeliveryStatusFailed, &errMsg)
+
+		if n.Attempts+1 >= queuedNotificationMaxAttempts {
+			if err := d.notificationRepo.DeleteQueued(ctx, n.ID); err != nil {
+				return sent, err
+			}
+			if err := d.markHistory(ctx, n.HistoryID, n.Payload.TriggeredAt, []string{fmt.Sprintf("%s: %v", n.Channel, sendErr)}); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		retryAt := d.clock.Now().Add(queuedNotificationRetryDelay)
+		if err := d.notificationRepo.RescheduleQueued(ctx, n.ID, retryAt, sendErr.Error()); err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// deliveries resolves the channels an alert should be delivered to
+func (d *notificationDispatcher) deliveries(ctx context.Context, alert *models.Alert) ([]delivery, error) {
+	if alert.Notification == (models.AlertNotification{}) {
+		return nil, nil
+	}
+
+	user, err := d.userRepo.GetByID(ctx, alert.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert owner: %w", err)
+	}
+
+	var deliveries []delivery
+	for _, channel := range d.channels.Channels() {
+		if address := channel.Address(alert, user); address != "" {
+			deliveries = append(deliveries, delivery{channel: channel.Name(), destination: address})
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (d *notificationDispatcher) send(ctx context.Context, userID uuid.UUID, channelName, destination string, message models.AlertNotificationMessage) error {
+	channel, ok := d.channels.Get(channelName)
+	if !ok {
+		return fmt.Errorf("unknown notification channel: %s", channelName)
+	}
+	if message.Text == "" {
+		message.Text = notificationText(message, channel.SupportsRichContent())
+	}
+	return channel.Send(ctx, NotificationRecipient{UserID: userID, Address: destination}, message)
+}
+
+// renderTemplate fills in the alert's message template
+func (d *notificationDispatcher) renderTemplate(ctx context.Context, alert *models.Alert, message models.AlertNotificationMessage) string {
+	if d.templates == nil {
+		return renderNotificationTemplate(alert.Notification.Template, notificationVariables(message))
+	}
+	return d.templates.Render(ctx, alert.UserID, alert.Notification.Template, message)
+}
+
+// recordDelivery appends to the delivery log. Failures are logged rather than returned
+// so analytics never block notification delivery.
+func (d *notificationDispatcher) recordDelivery(ctx context.Context, userID uuid.UUID, channel string, message models.AlertNotificationMessage, status string, errMsg *string) {
+	deliveredAt := d.clock.Now()
+	err := d.notificationRepo.RecordDelivery(ctx, &models.AlertDelivery{
+		DeliveryID:  deliveryID(message.HistoryID, channel),
+		AlertID:     message.AlertID,
+		HistoryID:   message.HistoryID,
+		UserID:      userID,
+		Channel:     channel,
+		Status:      status,
+		Error:       errMsg,
+		EvaluatedAt: message.TriggeredAt,
+		DeliveredAt: deliveredAt,
+		LatencyMs:   deliveredAt.Sub(message.TriggeredAt).Milliseconds(),
+	})
+	if err != nil {
+		logger.Warn("Failed to record alert delivery", "alertID", message.AlertID, "channel", channel, "error", err)
+	}
+}
+
+// deliveryID identifies delivering one trigger on one channel. It's derived rather than
+// stored so every attempt, immediate or queued, arrives at the same ID.
+func deliveryID(historyID uuid.UUID, channel string) uuid.UUID {
+	return uuid.NewSHA1(historyID, []byte(channel))
+}
+
+func (d *notificationDispatcher) markHistory(ctx context.Context, historyID uuid.UUID, triggeredAt time.Time, failures []string) error {
+	if len(failures) == 0 {
+		return d.notificationRepo.MarkHistoryNotified(ctx, historyID, triggeredAt, true, nil)
+	}
+	msg := strings.Join(failures, "; ")
+	return d.notificationRepo.MarkHistoryNotified(ctx, historyID, triggeredAt, false, &msg)
+}
+
+// quietHoursEnd reports whether now falls inside the user's quiet hours and, if so,
+// when they end. Windows where start > end wrap past midnight in the user's timezone.
+func quietHoursEnd(settings *models.NotificationSettings, now time.Time) (time.Time, bool, error) {
+	if settings == nil || !settings.QuietHoursEnabled {
+		return time.Time{}, false, nil
+	}
+
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid timezone %q: %w", settings.Timezone, err)
+	}
+	startH, startM, err := parseClock(settings.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	endH, endM, err := parseClock(settings.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	start := startH*60 + startM
+	end := endH*60 + endM
+	if start == end {
+		return time.Time{}, false, nil
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	endToday := time.Date(local.Year(), local.Month(), local.Day(), endH, endM, 0, 0, loc)
+
+	if start < end {
+		if minute >= start && minute < end {
+			return endToday, true, nil
+		}
+		return time.Time{}, false, nil
+	}
+
+	// Overnight window, e.g. 22:00-07:00
+	if minute < end {
+		return endToday, true, nil
+	}
+	if minute >= start {
+		return endToday.AddDate(0, 0, 1), true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// parseClock parses a 24-hour HH:MM time of day
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
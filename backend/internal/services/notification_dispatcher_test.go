@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	overnight := &models.NotificationSettings{
+		QuietHoursEnabled: true,
+		QuietHoursStart:   "22:00",
+		QuietHoursEnd:     "07:00",
+		Timezone:          "America/New_York",
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		settings  *models.NotificationSettings
+		now       time.Time
+		wantQuiet bool
+		wantEnd   time.Time
+	}{
+		{
+			name:      "before midnight rolls to next morning",
+			settings:  overnight,
+			now:       time.Date(2024, 3, 4, 23, 30, 0, 0, ny),
+			wantQuiet: true,
+			wantEnd:   time.Date(2024, 3, 5, 7, 0, 0, 0, ny),
+		},
+		{
+			name:      "after midnight ends same morning",
+			settings:  overnight,
+			now:       time.Date(2024, 3, 5, 6, 59, 0, 0, ny),
+			wantQuiet: true,
+			wantEnd:   time.Date(2024, 3, 5, 7, 0, 0, 0, ny),
+		},
+		{
+			name:      "outside window",
+			settings:  overnight,
+			now:       time.Date(2024, 3, 5, 7, 0, 0, 0, ny),
+			wantQuiet: false,
+		},
+		{
+			name:      "evaluated in user timezone",
+			settings:  overnight,
+			now:       time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC), // 22:00 in New York
+			wantQuiet: true,
+			wantEnd:   time.Date(2024, 3, 5, 7, 0, 0, 0, ny),
+		},
+		{
+			name: "daytime window",
+			settings: &models.NotificationSettings{
+				QuietHoursEnabled: true,
+				QuietHoursStart:   "09:00",
+				QuietHoursEnd:     "17:00",
+				Timezone:          "UTC",
+			},
+			now:       time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+			wantQuiet: true,
+			wantEnd:   time.Date(2024, 3, 5, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "disabled",
+			settings: &models.NotificationSettings{
+				QuietHoursStart: "00:00",
+				QuietHoursEnd:   "23:59",
+				Timezone:        "UTC",
+			},
+			now:       time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC),
+			wantQuiet: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, quiet, err := quietHoursEnd(tt.settings, tt.now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantQuiet, quiet)
+			if tt.wantQuiet {
+				assert.True(t, tt.wantEnd.Equal(end), "got %s, want %s", end, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestQuietHoursEnd_InvalidTimezone(t *testing.T) {
+	_, quiet, err := quietHoursEnd(&models.NotificationSettings{
+		QuietHoursEnabled: true,
+		QuietHoursStart:   "22:00",
+		QuietHoursEnd:     "07:00",
+		Timezone:          "Mars/Olympus",
+	}, time.Now())
+	assert.Error(t, err)
+	assert.False(t, quiet)
+}
+
+func TestDeliveryID(t *testing.T) {
+	historyID := uuid.New()
+
+	// Retries of the same history and channel must reuse the ID the first attempt recorded
+	assert.Equal(t, deliveryID(historyID, "email"), deliveryID(historyID, "email"))
+	assert.NotEqual(t, deliveryID(historyID, "email"), deliveryID(historyID, "webhook"))
+	assert.NotEqual(t, deliveryID(historyID, "email"), deliveryID(uuid.New(), "email"))
+}
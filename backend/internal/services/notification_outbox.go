@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// outboxLease is how long a claimed row is held before another dispatcher may retry it
+	outboxLease = 2 * time.Minute
+	// outboxBatchSize caps how many rows one sweep claims
+	outboxBatchSize = 100
+	// outboxMaxAttempts is how many sends a row gets before it's given up on
+	outboxMaxAttempts = 5
+	// outboxRetryDelay spaces out retries of a row whose channels failed
+	outboxRetryDelay = 5 * time.Minute
+	// outboxRetention is how long dispatched rows are kept for inspection
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// NotificationOutbox sends the notifications alert triggers write to the outbox in the
+// same transaction as their history. Rows are claimed before sending and every channel
+// delivery carries a stable ID, so a row retried after a crash or partial failure only
+// re-sends the channels that didn't go out.
+type NotificationOutbox interface {
+	// Dispatch sends one row straight away; it's a no-op if someone else holds the row
+	Dispatch(ctx context.Context, id uuid.UUID) error
+	// DispatchDue sends pending rows left behind by failed or interrupted dispatches
+	DispatchDue(ctx context.Context) (int, error)
+	// PurgeDispatched prunes rows dispatched longer ago than the retention period
+	PurgeDispatched(ctx context.Context) (int64, error)
+}
+
+type notificationOutbox struct {
+	outboxRepo repos.NotificationOutboxRepository
+	alertRepo  repos.AlertRepository
+	dispatcher NotificationDispatcher
+	// escalations takes over triggers of alerts with an escalation policy
+	escalations *EscalationService
+	clock       clock.Clock
+}
+
+func NewNotificationOutbox(outboxRepo repos.NotificationOutboxRepository, alertRepo repos.AlertRepository, dispatcher NotificationDispatcher) NotificationOutbox {
+	return &notificationOutbox{
+		outboxRepo: outboxRepo,
+		alertRepo:  alertRepo,
+		dispatcher: dispatcher,
+		clock:      clock.System,
+	}
+}
+
+// NewNotificationOutboxWithEscalations also escalates triggers of alerts with an
+// escalation policy through their team instead of only notifying the owner
+func NewNotificationOutboxWithEscalations(outboxRepo repos.NotificationOutboxRepository, alertRepo repos.AlertRepository, dispatcher NotificationDispatcher, escalations *EscalationService) NotificationOutbox {
+	return &notificationOutbox{
+		outboxRepo:  outboxRepo,
+		alertRepo:   alertRepo,
+		dispatcher:  dispatcher,
+		escalations: escalations,
+		clock:       clock.System,
+	}
+}
+
+func (o *notificationOutbox) Dispatch(ctx context.Context, id uuid.UUID) error {
+	n, err := o.outboxRepo.Claim(ctx, id, outboxLease)
+	if err != nil {
+		return err
+	}
+	if n == nil {
+		return nil
+	}
+	return o.deliver(ctx, n)
+}
+
+func (o *notificationOutbox) DispatchDue(ctx context.Context) (int, error) {
+	claimed, err := o.outboxRepo.ClaimDue(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for i := range claimed {
+		if err := o.deliver(ctx, &claimed[i]); err != nil {
+			logger.Warn("Failed to dispatch outbox notification",
+				"outboxId", claimed[i].ID,
+				"alertId", claimed[i].AlertID,
+				"attempt", claimed[i].Attempts,
+				"error", err)
+			continue
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
+
+func (o *notificationOutbox) PurgeDispatched(ctx context.Context) (int64, error) {
+	return o.outboxRepo.DeleteDispatchedBefore(ctx, o.clock.Now().Add(-outboxRetention))
+}
+
+// deliver sends a claimed row and settles it: dispatched on success or once out of
+// attempts, otherwise released for a later retry
+func (o *notificationOutbox) deliver(ctx context.Context, n *models.OutboxNotification) error {
+	alert, err := o.alertRepo.GetByID(ctx, n.AlertID)
+	if err != nil {
+		return o.settle(ctx, n, fmt.Errorf("failed to get alert: %w", err))
+	}
+	// The row records the channels chosen at trigger time, which may be narrower than the alert's
+	alert.Notification = n.Notification
+
+	// Muted alerts go to the dispatcher, which records them as dropped. An alert expiring
+	// isn't an incident, so it's never escalated.
+	muted := alert.MutedUntil != nil && o.clock.Now().Before(*alert.MutedUntil)
+	expiry := n.History.TriggeredValue["event"] == models.AlertTargetEventGone
+	if o.escalations != nil && alert.EscalationPolicyID != nil && !muted && !expiry {
+		escalated, err := o.escalations.Start(ctx, alert, &n.History)
+		if escalated || err != nil {
+			return o.settle(ctx, n, err)
+		}
+	}
+
+	if n.Urgent {
+		err = o.dispatcher.DispatchUrgent(ctx, alert, &n.History)
+	} else {
+		err = o.dispatcher.Dispatch(ctx, alert, &n.History)
+	}
+	return o.settle(ctx, n, err)
+}
+
+func (o *notificationOutbox) settle(ctx context.Context, n *models.OutboxNotification, sendErr error) error {
+	if sendErr == nil {
+		return o.outboxRepo.MarkDispatched(ctx, n.ID, nil)
+	}
+
+	msg := sendErr.Error()
+	if n.Attempts >= outboxMaxAttempts {
+		if err := o.outboxRepo.MarkDispatched(ctx, n.ID, &msg); err != nil {
+			return err
+		}
+		return sendErr
+	}
+	if err := o.outboxRepo.Release(ctx, n.ID, o.clock.Now().Add(outboxRetryDelay), msg); err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+type NotificationSettingsService interface {
+	GetSettings(ctx context.Context, userID uuid.UUID) (*models.NotificationSettings, error)
+	UpdateSettings(ctx context.Context, userID uuid.UUID, req *models.UpdateNotificationSettingsRequest) (*models.NotificationSettings, error)
+}
+
+type notificationSettingsService struct {
+	notificationRepo repos.NotificationRepository
+}
+
+func NewNotificationSettingsService(notificationRepo repos.NotificationRepository) NotificationSettingsService {
+	return &notificationSettingsService{notificationRepo: notificationRepo}
+}
+
+func (s *notificationSettingsService) GetSettings(ctx context.Context, userID uuid.UUID) (*models.NotificationSettings, error) {
+	return s.notificationRepo.GetSettings(ctx, userID)
+}
+
+func (s *notificationSettingsService) UpdateSettings(ctx context.Context, userID uuid.UUID, req *models.UpdateNotificationSettingsRequest) (*models.NotificationSettings, error) {
+	settings, err := s.notificationRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.QuietHoursEnabled != nil {
+		settings.QuietHoursEnabled = *req.QuietHoursEnabled
+	}
+	if req.QuietHoursStart != nil {
+		if _, _, err := parseClock(*req.QuietHoursStart); err != nil {
+			return nil, errors.BadRequest("quiet_hours_start must be HH:MM")
+		}
+		settings.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		if _, _, err := parseClock(*req.QuietHoursEnd); err != nil {
+			return nil, errors.BadRequest("quiet_hours_end must be HH:MM")
+		}
+		settings.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return nil, errors.BadRequest("Invalid timezone. Must be an IANA name such as Europe/Berlin")
+		}
+		settings.Timezone = *req.Timezone
+	}
+	if req.QuietHoursAction != nil {
+		if *req.QuietHoursAction != models.QuietHoursActionQueue && *req.QuietHoursAction != models.QuietHoursActionDrop {
+			return nil, errors.BadRequest("Invalid quiet_hours_action. Must be one of: queue, drop")
+		}
+		settings.QuietHoursAction = *req.QuietHoursAction
+	}
+
+	settings.UserID = userID
+	if err := s.notificationRepo.UpsertSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxNotificationTemplateLength caps the templates users store on alerts
+	maxNotificationTemplateLength = 500
+	// maxNotificationTextLength caps rendered messages, within what Discord accepts
+	maxNotificationTextLength = 2000
+	// maxTemplateValueLength caps each filled-in value, since token symbols and labels
+	// come from outside
+	maxTemplateValueLength = 100
+	// notificationTemplateValuePrefix exposes an alert's triggered values, e.g.
+	// {{value.tvlChangePercent}}
+	notificationTemplateValuePrefix = "value."
+)
+
+var (
+	templatePlaceholderPattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+	templateValueKeyPattern    = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// NotificationTemplateVariables are the placeholders message templates can use besides
+// the triggered values. Variables that don't apply to an alert render empty.
+var NotificationTemplateVariables = []models.NotificationTemplateVariable{
+	{Name: "alert.type", Description: "The alert's type", Example: "price above"},
+	{Name: "alert.id", Description: "The alert's ID", Example: "6f1c2a9e-0b7d-4c1e-9a55-3f2b8d4e7a10"},
+	{Name: "target", Description: "What the alert watches: a token or wallet address, pool ID or protocol", Example: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"},
+	{Name: "chain.id", Description: "Chain ID of the alert's target", Example: "1"},
+	{Name: "chain.name", Description: "Chain of the alert's target", Example: "Ethereum"},
+	{Name: "token.symbol", Description: "Symbol of the token the alert is about", Example: "USDC"},
+	{Name: "token.name", Description: "Name of the token the alert is about", Example: "USD Coin"},
+	{Name: "wallet.label", Description: "Label of your wallet the alert is about, or its address when it has none", Example: "Main wallet"},
+	{Name: "wallet.address", Description: "Address of the wallet the alert is about", Example: "0x742d35cc6634c0532925a3b844bc454e4438f44e"},
+	{Name: "price", Description: "Token price that triggered a price alert", Example: "2000.50"},
+	{Name: "triggered_at", Description: "When the alert triggered, in UTC", Example: "2024-06-01 12:00 UTC"},
+	{Name: notificationTemplateValuePrefix + "<key>", Description: "Any value the alert triggered with", Example: "{{value.tvlChangePercent}}"},
+}
+
+// NotificationTemplateLibrary is the set of ready-made templates users pick from
+var NotificationTemplateLibrary = []models.NotificationTemplate{
+	{
+		Name:        "price_move",
+		AlertTypes:  []string{models.AlertTypePriceAbove, models.AlertTypePriceBelow},
+		Description: "Token price with the chain it trades on",
+		Template:    "{{token.symbol}} is now ${{price}} on {{chain.name}}",
+	},
+	{
+		Name:        "pool_apr",
+		AlertTypes:  []string{models.AlertTypeAPRChange},
+		Description: "Pool APR and which bound it crossed",
+		Template:    "Pool {{target}} APR is {{value.currentAPR}}% ({{value.reason}})",
+	},
+	{
+		Name:        "liquidity_move",
+		AlertTypes:  []string{models.AlertTypeLiquidityChange},
+		Description: "TVL change over the last 24 hours",
+		Template:    "TVL of {{target}} moved {{value.tvlChangePercent}}% in 24h",
+	},
+	{
+		Name:        "wallet_activity",
+		AlertTypes:  []string{models.AlertTypeLargeTransfer, models.AlertTypeApproval, models.AlertTypeBalanceChange},
+		Description: "Activity on one of your wallets, by its label",
+		Template:    "{{alert.type}} on {{wallet.label}} at {{triggered_at}}",
+	},
+	{
+		Name:        "portfolio_value",
+		AlertTypes:  []string{models.AlertTypePortfolioValue},
+		Description: "Total value of your portfolio",
+		Template:    "Your portfolio is now worth ${{value.portfolioValueUsd}}",
+	},
+	{
+		Name:        "generic",
+		Description: "Alert type and target, for any alert",
+		Template:    "{{alert.type}} alert triggered on {{target}} at {{triggered_at}}",
+	},
+}
+
+// exampleTriggeredValues are what previews fill in for alert types when the request
+// doesn't give triggered values
+var exampleTriggeredValues = map[string]map[string]interface{}{
+	models.AlertTypePriceAbove:      {"currentPrice": 2000.5},
+	models.AlertTypePriceBelow:      {"currentPrice": 1850.25},
+	models.AlertTypeAPRChange:       {"currentAPR": 3.2, "reason": "below_min_apr"},
+	models.AlertTypeLiquidityChange: {"tvlChangePercent": -12.5, "threshold": 10.0},
+	models.AlertTypePortfolioValue:  {"portfolioValueUsd": 25000.0},
+	models.AlertTypeLargeTransfer:   {"transferAmount": "2500000000000000000", "transferAmountUsd": 5000.0, "thresholdUsd": 1000.0, "chainId": 1},
+}
+
+// ValidateNotificationTemplate checks a template's length and that every placeholder
+// names a known variable
+func ValidateNotificationTemplate(template string) error {
+	if utf8.RuneCountInString(template) > maxNotificationTemplateLength {
+		return fmt.Errorf("template must be at most %d characters", maxNotificationTemplateLength)
+	}
+
+	for _, match := range templatePlaceholderPattern.FindAllStringSubmatch(template, -1) {
+		if !isNotificationTemplateVariable(match[1]) {
+			return fmt.Errorf("unknown template variable %q", match[1])
+		}
+	}
+	rest := templatePlaceholderPattern.ReplaceAllString(template, "")
+	if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return fmt.Errorf("template has an unclosed placeholder")
+	}
+	return nil
+}
+
+func isNotificationTemplateVariable(name string) bool {
+	if key, ok := strings.CutPrefix(name, notificationTemplateValuePrefix); ok {
+		return templateValueKeyPattern.MatchString(key)
+	}
+	for _, variable := range NotificationTemplateVariables {
+		if variable.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renderNotificationTemplate fills in a template's placeholders in one pass, so values
+// that look like placeholders aren't expanded themselves
+func renderNotificationTemplate(template string, variables map[string]string) string {
+	text := templatePlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := templatePlaceholderPattern.FindStringSubmatch(placeholder)[1]
+		return variables[name]
+	})
+	if utf8.RuneCountInString(text) > maxNotificationTextLength {
+		text = string([]rune(text)[:maxNotificationTextLength])
+	}
+	return text
+}
+
+// notificationVariables returns the template variables a message carries itself
+func notificationVariables(message models.AlertNotificationMessage) map[string]string {
+	variables := map[string]string{
+		"alert.type":   strings.ReplaceAll(message.Type, "_", " "),
+		"alert.id":     message.AlertID.String(),
+		"target":       message.Target.Identifier,
+		"triggered_at": message.TriggeredAt.UTC().Format("2006-01-02 15:04 UTC"),
+	}
+	if message.Target.ChainID != 0 {
+		variables["chain.id"] = strconv.Itoa(message.Target.ChainID)
+		variables["chain.name"] = blockchain.GetChainName(message.Target.ChainID)
+	}
+	for key, value := range message.TriggeredValue {
+		variables[notificationTemplateValuePrefix+key] = formatTemplateValue(value)
+	}
+	if price, ok := message.TriggeredValue["currentPrice"]; ok {
+		variables["price"] = formatTemplateValue(price)
+	}
+	if symbol, ok := message.TriggeredValue["tokenSymbol"].(string); ok {
+		variables["token.symbol"] = symbol
+	}
+
+	for name, value := range variables {
+		variables[name] = sanitizeTemplateValue(value)
+	}
+	return variables
+}
+
+// formatTemplateValue writes amounts of at least 1 to the cent and smaller ones to six
+// significant digits
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		if math.Abs(v) >= 1 {
+			return strconv.FormatFloat(v, 'f', 2, 64)
+		}
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return formatTemplateValue(*v)
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format("2006-01-02 15:04 UTC")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sanitizeTemplateValue drops control characters, so a value can't add lines or escape
+// sequences to a message, and caps its length
+func sanitizeTemplateValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+	if utf8.RuneCountInString(value) > maxTemplateValueLength {
+		value = string([]rune(value)[:maxTemplateValueLength])
+	}
+	return value
+}
+
+type notificationTemplateTokens interface {
+	GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error)
+}
+
+type notificationTemplateWallets interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Wallet, error)
+}
+
+// NotificationTemplateService renders the message templates users set on alerts,
+// looking up the token and wallet variables for the alert's target
+type NotificationTemplateService struct {
+	tokens  notificationTemplateTokens
+	wallets notificationTemplateWallets
+	clock   clock.Clock
+}
+
+func NewNotificationTemplateService(tokenRepo repos.TokenRepository, walletRepo repos.WalletRepository) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		tokens:  tokenRepo,
+		wallets: walletRepo,
+		clock:   clock.System,
+	}
+}
+
+// Render fills in a template for a message of the user's alert
+func (s *NotificationTemplateService) Render(ctx context.Context, userID uuid.UUID, template string, message models.AlertNotificationMessage) string {
+	return renderNotificationTemplate(template, s.variables(ctx, userID, message))
+}
+
+// Preview renders a template for an alert definition, with example triggered values
+// unless the request gives some
+func (s *NotificationTemplateService) Preview(ctx context.Context, userID uuid.UUID, req *models.PreviewNotificationTemplateRequest) (*models.NotificationTemplatePreview, error) {
+	if strings.TrimSpace(req.Template) == "" {
+		return nil, errors.BadRequest("Template is required")
+	}
+	if err := ValidateNotificationTemplate(req.Template); err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+	target := req.Target
+	if err := target.ResolveAsset(); err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+
+	triggeredValue := req.TriggeredValue
+	if triggeredValue == nil {
+		triggeredValue = exampleTriggeredValues[req.Type]
+	}
+	variables := s.variables(ctx, userID, models.AlertNotificationMessage{
+		Type:           req.Type,
+		Target:         target,
+		TriggeredAt:    s.clock.Now(),
+		TriggeredValue: triggeredValue,
+	})
+
+	return &models.NotificationTemplatePreview{
+		Text:      renderNotificationTemplate(req.Template, variables),
+		Variables: variables,
+	}, nil
+}
+
+// variables adds the target's token and the user's wallet to the message's own
+// variables. Failed lookups only leave their variables out.
+func (s *NotificationTemplateService) variables(ctx context.Context, userID uuid.UUID, message models.AlertNotificationMessage) map[string]string {
+	variables := notificationVariables(message)
+	target := message.Target
+
+	if target.Type == "token" && target.Identifier != "" {
+		tokens, err := s.tokens.GetTokensByAddresses(ctx, target.ChainID, []string{target.Identifier})
+		if err != nil {
+			logger.Warn("Failed to look up token for notification template", "token", target.Identifier, "error", err)
+		} else if token, ok := tokens[strings.ToLower(target.Identifier)]; ok {
+			variables["token.symbol"] = sanitizeTemplateValue(token.Symbol)
+			variables["token.name"] = sanitizeTemplateValue(token.Name)
+		}
+	}
+
+	address := ""
+	if target.Type == "address" {
+		address = target.Identifier
+	} else if a, ok := message.TriggeredValue["address"].(string); ok {
+		address = a
+	}
+	if address == "" {
+		return variables
+	}
+	variables["wallet.address"] = sanitizeTemplateValue(address)
+	variables["wallet.label"] = variables["wallet.address"]
+
+	wallets, err := s.wallets.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to look up wallets for notification template", "userID", userID, "error", err)
+		return variables
+	}
+	for _, wallet := range wallets {
+		if strings.EqualFold(wallet.Address, address) && wallet.Label != nil && *wallet.Label != "" {
+			variables["wallet.label"] = sanitizeTemplateValue(*wallet.Label)
+			break
+		}
+	}
+	return variables
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type templateTokens map[string]*models.Token
+
+func (t templateTokens) GetTokensByAddresses(context.Context, int, []string) (map[string]*models.Token, error) {
+	return t, nil
+}
+
+type templateWallets []*models.Wallet
+
+func (w templateWallets) GetByUserID(context.Context, uuid.UUID) ([]*models.Wallet, error) {
+	return w, nil
+}
+
+func TestValidateNotificationTemplate(t *testing.T) {
+	assert.NoError(t, ValidateNotificationTemplate(""))
+	assert.NoError(t, ValidateNotificationTemplate("{{token.symbol}} at ${{ price }} ({{value.tvlChangePercent}})"))
+	for _, library := range NotificationTemplateLibrary {
+		assert.NoError(t, ValidateNotificationTemplate(library.Template), library.Name)
+	}
+
+	assert.ErrorContains(t, ValidateNotificationTemplate("{{user.email}}"), "unknown template variable")
+	assert.ErrorContains(t, ValidateNotificationTemplate("{{value.a-b}}"), "unknown template variable")
+	assert.ErrorContains(t, ValidateNotificationTemplate("{{price} is up"), "unclosed placeholder")
+	assert.Error(t, ValidateNotificationTemplate(string(make([]byte, maxNotificationTemplateLength+1))))
+}
+
+func TestRenderNotificationTemplate(t *testing.T) {
+	message := models.AlertNotificationMessage{
+		Type:        models.AlertTypePriceAbove,
+		Target:      models.AlertTarget{Type: "token", Identifier: "0xabc", ChainID: 1},
+		TriggeredAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		TriggeredValue: map[string]interface{}{
+			"currentPrice": 2000.456,
+			// Values that look like placeholders aren't expanded, and can't add lines
+			"tokenSymbol": "{{alert.id}}\nX",
+			"ratio":       0.000123456789,
+		},
+	}
+
+	text := renderNotificationTemplate("{{alert.type}}: {{token.symbol}} ${{price}} on {{chain.name}} at {{triggered_at}}, {{value.ratio}}{{wallet.label}}", notificationVariables(message))
+	assert.Equal(t, "price above: {{alert.id}}X $2000.46 on Ethereum at 2024-06-01 12:00 UTC, 0.000123457", text)
+}
+
+func TestNotificationTemplatePreview(t *testing.T) {
+	label := "Main wallet"
+	s := &NotificationTemplateService{
+		tokens: templateTokens{"0xa0b8": {Symbol: "USDC", Name: "USD Coin"}},
+		wallets: templateWallets{
+			{Address: "0x742d35cc6634c0532925a3b844bc454e4438f44e", Label: &label},
+		},
+		clock: clock.NewFake(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)),
+	}
+
+	preview, err := s.Preview(context.Background(), uuid.New(), &models.PreviewNotificationTemplateRequest{
+		Template: "{{token.symbol}} ({{token.name}}) is ${{price}}",
+		Type:     models.AlertTypePriceAbove,
+		Target:   models.AlertTarget{Type: "token", Identifier: "0xA0B8", ChainID: 1},
+	})
+	require.NoError(t, err)
+	// Example values fill in for the alert type
+	assert.Equal(t, "USDC (USD Coin) is $2000.50", preview.Text)
+
+	preview, err = s.Preview(context.Background(), uuid.New(), &models.PreviewNotificationTemplateRequest{
+		Template:       "{{wallet.label}} moved {{value.changeUsd}}",
+		Type:           models.AlertTypeBalanceChange,
+		Target:         models.AlertTarget{Type: "address", Identifier: "0x742D35CC6634C0532925A3B844BC454E4438F44E"},
+		TriggeredValue: map[string]interface{}{"changeUsd": 1500.0},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Main wallet moved 1500.00", preview.Text)
+
+	_, err = s.Preview(context.Background(), uuid.New(), &models.PreviewNotificationTemplateRequest{Template: "{{secret}}"})
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.Status)
+}
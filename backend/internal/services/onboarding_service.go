@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// onboardingStep is a step of the guided setup, with the steps it needs first
+type onboardingStep struct {
+	step     string
+	requires []string
+	hint     string
+}
+
+// onboardingSteps are the guided setup's steps in order
+var onboardingSteps = []onboardingStep{
+	{step: models.OnboardingStepWalletAdded, hint: "Add a wallet to track its balances, transactions and positions."},
+	{step: models.OnboardingStepFirstSync, requires: []string{models.OnboardingStepWalletAdded},
+		hint: "Once a wallet is added its history is imported, which usually takes a few minutes."},
+	{step: models.OnboardingStepFirstAlert, hint: "Create an alert to hear about price moves, large transfers or portfolio swings."},
+	{step: models.OnboardingStepEmailVerified, hint: "Verify your email to receive alerts and monthly statements by email."},
+}
+
+// OnboardingService tracks users' progress through the guided setup. Services record a
+// step as the user completes it, which also pushes a realtime event to the frontend;
+// steps done before they were tracked, or without going through a service, are picked
+// up from the user's data when progress is read.
+type OnboardingService struct {
+	repo      repos.OnboardingRepository
+	publisher events.Publisher
+	clock     clock.Clock
+}
+
+// NewOnboardingService records steps in repo and announces them through publisher,
+// which may be nil
+func NewOnboardingService(repo repos.OnboardingRepository, publisher events.Publisher) *OnboardingService {
+	return &OnboardingService{repo: repo, publisher: publisher, clock: clock.System}
+}
+
+// GetProgress returns the user's progress with each step's state and hint
+func (s *OnboardingService) GetProgress(ctx context.Context, userID uuid.UUID) (*models.OnboardingProgress, error) {
+	if err := s.repo.Reconcile(ctx, userID); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	completed, err := s.repo.GetSteps(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return onboardingProgress(completed), nil
+}
+
+// CompleteStep records that the user completed the step. Onboarding never fails what
+// the user was doing, so errors are only logged.
+func (s *OnboardingService) CompleteStep(ctx context.Context, userID uuid.UUID, step string) {
+	recorded, err := s.repo.CompleteStep(ctx, userID, step)
+	if err != nil {
+		logger.Error("Failed to record onboarding step", "error", err, "userID", userID, "step", step)
+		return
+	}
+	if recorded {
+		s.announce(ctx, userID, step)
+	}
+}
+
+// CompleteWalletStep is CompleteStep for the owner of the wallet
+func (s *OnboardingService) CompleteWalletStep(ctx context.Context, walletID uuid.UUID, step string) {
+	userID, recorded, err := s.repo.CompleteWalletStep(ctx, walletID, step)
+	if err != nil {
+		logger.Error("Failed to record onboarding step", "error", err, "walletID", walletID, "step", step)
+		return
+	}
+	if recorded {
+		s.announce(ctx, userID, step)
+	}
+}
+
+func (s *OnboardingService) announce(ctx context.Context, userID uuid.UUID, step string) {
+	if s.publisher == nil {
+		return
+	}
+	event, err := events.NewEvent(events.TypeOnboardingStep, userID, map[string]interface{}{
+		"step":         step,
+		"completed_at": s.clock.Now().UTC(),
+	})
+	if err == nil {
+		err = s.publisher.Publish(ctx, event)
+	}
+	if err != nil {
+		logger.Warn("Failed to publish onboarding step", "error", err, "userID", userID, "step", step)
+	}
+}
+
+// AlertService wraps alerts so creating one completes the first alert step
+func (s *OnboardingService) AlertService(alerts AlertService) AlertService {
+	return &onboardingAlertService{AlertService: alerts, onboarding: s}
+}
+
+type onboardingAlertService struct {
+	AlertService
+	onboarding *OnboardingService
+}
+
+func (s *onboardingAlertService) CreateAlert(ctx context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.Alert, error) {
+	alert, err := s.AlertService.CreateAlert(ctx, userID, req)
+	if err == nil {
+		s.onboarding.CompleteStep(ctx, userID, models.OnboardingStepFirstAlert)
+	}
+	return alert, err
+}
+
+// onboardingProgress lays the completed steps out over the guided setup
+func onboardingProgress(completed map[string]time.Time) *models.OnboardingProgress {
+	progress := &models.OnboardingProgress{
+		Total: len(onboardingSteps),
+		Steps: make([]models.OnboardingStep, 0, len(onboardingSteps)),
+	}
+	for _, definition := range onboardingSteps {
+		step := models.OnboardingStep{Step: definition.step}
+		if completedAt, ok := completed[definition.step]; ok {
+			step.Status = models.OnboardingStepCompleted
+			step.CompletedAt = &completedAt
+			progress.Completed++
+		} else {
+			step.Status = models.OnboardingStepPending
+			step.Hint = definition.hint
+			for _, required := range definition.requires {
+				if _, ok := completed[required]; !ok {
+					step.Status = models.OnboardingStepLocked
+				}
+			}
+		}
+		progress.Steps = append(progress.Steps, step)
+	}
+
+	for i := range progress.Steps {
+		if progress.Steps[i].Status == models.OnboardingStepPending {
+			next := progress.Steps[i]
+			progress.NextStep = &next
+			break
+		}
+	}
+
+	switch progress.Completed {
+	case 0:
+		progress.Status = models.OnboardingNotStarted
+	case progress.Total:
+		progress.Status = models.OnboardingCompleted
+	default:
+		progress.Status = models.OnboardingInProgress
+	}
+	return progress
+}
\ No newline at end of file
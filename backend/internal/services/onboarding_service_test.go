@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOnboarding records steps in memory; reconciled holds the steps the user's data shows
+type memoryOnboarding struct {
+	steps      map[string]time.Time
+	reconciled map[string]time.Time
+	owner      uuid.UUID
+}
+
+func (m *memoryOnboarding) GetSteps(context.Context, uuid.UUID) (map[string]time.Time, error) {
+	return m.steps, nil
+}
+
+func (m *memoryOnboarding) CompleteStep(_ context.Context, _ uuid.UUID, step string) (bool, error) {
+	if _, ok := m.steps[step]; ok {
+		return false, nil
+	}
+	m.steps[step] = time.Now()
+	return true, nil
+}
+
+func (m *memoryOnboarding) CompleteWalletStep(ctx context.Context, _ uuid.UUID, step string) (uuid.UUID, bool, error) {
+	recorded, err := m.CompleteStep(ctx, m.owner, step)
+	return m.owner, recorded, err
+}
+
+func (m *memoryOnboarding) Reconcile(context.Context, uuid.UUID) error {
+	for step, at := range m.reconciled {
+		if _, ok := m.steps[step]; !ok {
+			m.steps[step] = at
+		}
+	}
+	return nil
+}
+
+type recordedEvents struct {
+	events []events.Event
+}
+
+func (r *recordedEvents) Publish(_ context.Context, event events.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// createdAlerts accepts alerts with conditions and rejects the rest
+type createdAlerts struct {
+	AlertService
+}
+
+func (createdAlerts) CreateAlert(_ context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.Alert, error) {
+	if req.Conditions.Price == nil {
+		return nil, fmt.Errorf("invalid alert conditions")
+	}
+	return &models.Alert{ID: uuid.New(), UserID: userID, Type: req.Type}, nil
+}
+
+func TestOnboardingProgress(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	added := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	repo := &memoryOnboarding{steps: map[string]time.Time{}, reconciled: map[string]time.Time{}, owner: userID}
+	published := &recordedEvents{}
+	service := NewOnboardingService(repo, published)
+
+	progress, err := service.GetProgress(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OnboardingNotStarted, progress.Status)
+	assert.Equal(t, 4, progress.Total)
+	require.NotNil(t, progress.NextStep)
+	assert.Equal(t, models.OnboardingStepWalletAdded, progress.NextStep.Step)
+	assert.NotEmpty(t, progress.NextStep.Hint)
+	// The first sync waits for a wallet
+	assert.Equal(t, models.OnboardingStepLocked, progress.Steps[1].Status)
+
+	// A wallet the app added shows up from the data
+	repo.reconciled[models.OnboardingStepWalletAdded] = added
+	progress, err = service.GetProgress(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OnboardingInProgress, progress.Status)
+	assert.Equal(t, 1, progress.Completed)
+	assert.Equal(t, added, *progress.Steps[0].CompletedAt)
+	assert.Empty(t, progress.Steps[0].Hint)
+	assert.Equal(t, models.OnboardingStepPending, progress.Steps[1].Status)
+	assert.Equal(t, models.OnboardingStepFirstSync, progress.NextStep.Step)
+	assert.Empty(t, published.events, "reconciled steps aren't announced")
+
+	// Steps recorded by services are announced once
+	service.CompleteWalletStep(ctx, uuid.New(), models.OnboardingStepFirstSync)
+	alerts := service.AlertService(createdAlerts{})
+	_, err = alerts.CreateAlert(ctx, userID, &models.CreateAlertRequest{Type: models.AlertTypePriceAbove})
+	require.Error(t, err)
+	price := 2000.0
+	for i := 0; i < 2; i++ {
+		_, err = alerts.CreateAlert(ctx, userID, &models.CreateAlertRequest{Type: models.AlertTypePriceAbove, Conditions: models.AlertConditions{Price: &price}})
+		require.NoError(t, err)
+	}
+	require.Len(t, published.events, 2)
+	assert.Equal(t, events.TypeOnboardingStep, published.events[1].Type)
+	assert.Equal(t, userID, published.events[1].UserID)
+	assert.Contains(t, string(published.events[1].Data), models.OnboardingStepFirstAlert)
+
+	progress, err = service.GetProgress(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, progress.Completed)
+	assert.Equal(t, models.OnboardingStepEmailVerified, progress.NextStep.Step)
+
+	service.CompleteStep(ctx, userID, models.OnboardingStepEmailVerified)
+	progress, err = service.GetProgress(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OnboardingCompleted, progress.Status)
+	assert.Nil(t, progress.NextStep)
+}
\ No newline at end of file
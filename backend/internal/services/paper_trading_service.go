@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxPaperPortfolios caps how many paper portfolios one user can have
+	maxPaperPortfolios = 10
+	// defaultPaperCashUSD is what a paper portfolio starts with unless asked otherwise
+	defaultPaperCashUSD = 10000
+	// maxPaperCashUSD bounds the starting cash so totals stay well within float precision
+	maxPaperCashUSD = 1e9
+)
+
+// PaperTradingService runs simulated portfolios: users buy, sell and receive yield at
+// live prices with paper cash, and see the result through the same PnL calculator as
+// their real holdings
+type PaperTradingService struct {
+	paperRepo repos.PaperPortfolioRepository
+}
+
+func NewPaperTradingService(paperRepo repos.PaperPortfolioRepository) *PaperTradingService {
+	return &PaperTradingService{paperRepo: paperRepo}
+}
+
+func (s *PaperTradingService) Create(ctx context.Context, userID uuid.UUID, req *models.CreatePaperPortfolioRequest) (*models.PaperPortfolio, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.BadRequest("Name is required")
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		return nil, errors.BadRequest("Name must be at most 100 characters")
+	}
+	cash := float64(defaultPaperCashUSD)
+	if req.StartingCashUSD != nil {
+		cash = *req.StartingCashUSD
+	}
+	if !(cash > 0) || cash > maxPaperCashUSD {
+		return nil, errors.BadRequest("Starting cash must be more than 0 and at most 1000000000")
+	}
+
+	existing, err := s.paperRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if len(existing) >= maxPaperPortfolios {
+		return nil, errors.BadRequest("Paper portfolio limit reached; delete one to start another")
+	}
+
+	portfolio := &models.PaperPortfolio{UserID: userID, Name: name, StartingCashUSD: cash}
+	if err := s.paperRepo.Create(ctx, portfolio); err != nil {
+		if err == repos.ErrPaperPortfolioNameTaken {
+			return nil, errors.New("PAPER_PORTFOLIO_EXISTS", "A paper portfolio with this name already exists", 409)
+		}
+		return nil, errors.DatabaseError(err)
+	}
+	return portfolio, nil
+}
+
+// List returns the user's paper portfolios by name
+func (s *PaperTradingService) List(ctx context.Context, userID uuid.UUID) ([]*models.PaperPortfolio, error) {
+	portfolios, err := s.paperRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return portfolios, nil
+}
+
+func (s *PaperTradingService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	deleted, err := s.paperRepo.Delete(ctx, id, userID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Paper portfolio")
+	}
+	return nil
+}
+
+// GetTrades lists the portfolio's trades, oldest first
+func (s *PaperTradingService) GetTrades(ctx context.Context, userID, id uuid.UUID) ([]*models.PaperTrade, error) {
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	trades, err := s.paperRepo.GetTrades(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return trades, nil
+}
+
+// Trade executes a simulated trade at the asset's live price
+func (s *PaperTradingService) Trade(ctx context.Context, userID, id uuid.UUID, req *models.PaperTradeRequest, coinGeckoAPIKey string) (*models.PaperTrade, *models.PaperPortfolio, error) {
+	if _, err := s.get(ctx, userID, id); err != nil {
+		return nil, nil, err
+	}
+	if req.Side != models.PaperTradeBuy && req.Side != models.PaperTradeSell && req.Side != models.PaperTradeYield {
+		return nil, nil, errors.BadRequest("Side must be buy, sell or yield")
+	}
+	asset := strings.ToUpper(strings.TrimSpace(req.Asset))
+	if asset == "" || len(asset) > 20 {
+		return nil, nil, errors.BadRequest("Asset must be a symbol of at most 20 characters")
+	}
+	if (req.Quantity > 0) == (req.AmountUSD > 0) || req.Quantity < 0 || req.AmountUSD < 0 {
+		return nil, nil, errors.BadRequest("Set either a positive quantity or a positive amount_usd")
+	}
+
+	prices := paperPrices(ctx, []string{asset}, coinGeckoAPIKey)
+	price, ok := prices[asset]
+	if !ok || price <= 0 {
+		return nil, nil, errors.BadRequest(fmt.Sprintf("No live price for %s", asset))
+	}
+	quantity := req.Quantity
+	if req.AmountUSD > 0 {
+		quantity = req.AmountUSD / price
+	}
+	if math.IsInf(quantity, 0) || math.IsNaN(quantity) {
+		return nil, nil, errors.BadRequest("Invalid quantity")
+	}
+
+	trade := &models.PaperTrade{PortfolioID: id, Side: req.Side, Asset: asset, Quantity: quantity, PriceUSD: price}
+	portfolio, err := s.paperRepo.AddTrade(ctx, trade)
+	switch err {
+	case nil:
+		return trade, portfolio, nil
+	case repos.ErrPaperInsufficientCash:
+		return nil, nil, errors.BadRequest("Not enough paper cash for this buy")
+	case repos.ErrPaperInsufficientHoldings:
+		return nil, nil, errors.BadRequest(fmt.Sprintf("Not enough %s to sell", asset))
+	default:
+		return nil, nil, errors.DatabaseError(err)
+	}
+}
+
+// GetSummary values the portfolio's positions at live prices, with each asset's PnL
+// computed FIFO. Positions that can't be priced are listed without a value.
+func (s *PaperTradingService) GetSummary(ctx context.Context, userID, id uuid.UUID, coinGeckoAPIKey string) (*models.PaperPortfolioSummary, error) {
+	portfolio, err := s.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := s.paperRepo.GetTrades(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	prices := paperPrices(ctx, paperAssets(trades), coinGeckoAPIKey)
+	summary, err := summarizePaperPortfolio(portfolio, trades, prices)
+	if err != nil {
+		logger.Error("Failed to calculate paper PnL", "error", err, "portfolioID", id)
+		return nil, errors.Internal("Failed to calculate PnL")
+	}
+	return summary, nil
+}
+
+func (s *PaperTradingService) get(ctx context.Context, userID, id uuid.UUID) (*models.PaperPortfolio, error) {
+	portfolio, err := s.paperRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if portfolio == nil || portfolio.UserID != userID {
+		return nil, errors.NotFound("Paper portfolio")
+	}
+	return portfolio, nil
+}
+
+// paperPrices returns live USD prices of the assets; USD stablecoins count as 1 when
+// unpriced. Assets without a price are left out.
+func paperPrices(ctx context.Context, assets []string, coinGeckoAPIKey string) map[string]float64 {
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys("", coinGeckoAPIKey)
+	prices, err := blockchainService.GetSymbolPricesUSD(ctx, assets)
+	if err != nil {
+		logger.Warn("Failed to get paper asset prices", "error", err)
+	}
+	if prices == nil {
+		prices = map[string]float64{}
+	}
+	for _, asset := range assets {
+		if _, ok := prices[asset]; !ok && external.IsUSDAsset(asset) {
+			prices[asset] = 1
+		}
+	}
+	return prices
+}
+
+// paperAssets lists the assets traded by symbol
+func paperAssets(trades []*models.PaperTrade) []string {
+	seen := make(map[string]bool)
+	var assets []string
+	for _, trade := range trades {
+		if !seen[trade.Asset] {
+			seen[trade.Asset] = true
+			assets = append(assets, trade.Asset)
+		}
+	}
+	sort.Strings(assets)
+	return assets
+}
+
+// summarizePaperPortfolio runs each traded asset's lots through the PnL calculator and
+// values the holdings at the given prices
+func summarizePaperPortfolio(portfolio *models.PaperPortfolio, trades []*models.PaperTrade, prices map[string]float64) (*models.PaperPortfolioSummary, error) {
+	summary := &models.PaperPortfolioSummary{
+		Portfolio:     portfolio,
+		Positions:     []*models.PaperPosition{},
+		TotalValueUSD: portfolio.CashUSD,
+	}
+
+	for _, asset := range paperAssets(trades) {
+		price, priced := prices[asset]
+		current := "0"
+		if priced {
+			current = fmt.Sprintf("%.10f", price)
+		}
+		calculation, err := pnl.NewCalculator(pnl.FIFO).CalculatePnL(pnl.PaperLots(trades, asset), current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate %s PnL: %w", asset, err)
+		}
+
+		position := &models.PaperPosition{
+			Asset:          asset,
+			Quantity:       calculation.CurrentQuantity,
+			CostBasisUSD:   calculation.TotalCostBasisUSD,
+			RealizedPnLUSD: calculation.RealizedPnLUSD,
+		}
+		for _, trade := range trades {
+			if trade.Asset == asset && trade.Side == models.PaperTradeYield {
+				position.YieldUSD += trade.Quantity * trade.PriceUSD
+			}
+		}
+		if priced {
+			quantity, _ := strconv.ParseFloat(calculation.CurrentQuantity, 64)
+			value := quantity * price
+			position.PriceUSD, position.ValueUSD = &price, &value
+			position.UnrealizedPnLUSD = calculation.UnrealizedPnLUSD
+			summary.TotalValueUSD += value
+		}
+		summary.Positions = append(summary.Positions, position)
+	}
+
+	if portfolio.StartingCashUSD > 0 {
+		summary.ReturnPct = (summary.TotalValueUSD - portfolio.StartingCashUSD) / portfolio.StartingCashUSD * 100
+	}
+	return summary, nil
+}
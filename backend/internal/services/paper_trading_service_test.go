@@ -0,0 +1,49 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizePaperPortfolio(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	portfolio := &models.PaperPortfolio{StartingCashUSD: 10000, CashUSD: 7000}
+	trades := []*models.PaperTrade{
+		{ID: uuid.New(), Side: models.PaperTradeBuy, Asset: "ETH", Quantity: 2, PriceUSD: 2000, ExecutedAt: start},
+		{ID: uuid.New(), Side: models.PaperTradeSell, Asset: "ETH", Quantity: 1, PriceUSD: 3000, ExecutedAt: start.Add(time.Hour)},
+		{ID: uuid.New(), Side: models.PaperTradeYield, Asset: "ETH", Quantity: 0.5, PriceUSD: 2000, ExecutedAt: start.Add(2 * time.Hour)},
+		{ID: uuid.New(), Side: models.PaperTradeBuy, Asset: "XYZ", Quantity: 100, PriceUSD: 20, ExecutedAt: start},
+	}
+
+	summary, err := summarizePaperPortfolio(portfolio, trades, map[string]float64{"ETH": 4000})
+	require.NoError(t, err)
+	require.Len(t, summary.Positions, 2)
+
+	eth := summary.Positions[0]
+	assert.Equal(t, "ETH", eth.Asset)
+	require.NotNil(t, eth.ValueUSD)
+	assert.InDelta(t, 6000, *eth.ValueUSD, 1e-6)
+	assert.InDelta(t, 1000, parseFloat(t, eth.RealizedPnLUSD), 1e-6)
+	assert.InDelta(t, 3000, parseFloat(t, eth.UnrealizedPnLUSD), 1e-6)
+	assert.InDelta(t, 1000, eth.YieldUSD, 1e-6)
+
+	xyz := summary.Positions[1]
+	assert.Nil(t, xyz.ValueUSD, "unpriced assets have no value")
+	assert.Empty(t, xyz.UnrealizedPnLUSD)
+
+	assert.InDelta(t, 13000, summary.TotalValueUSD, 1e-6)
+	assert.InDelta(t, 30, summary.ReturnPct, 1e-9)
+}
+
+func parseFloat(t *testing.T, s string) float64 {
+	t.Helper()
+	f, err := strconv.ParseFloat(s, 64)
+	require.NoError(t, err)
+	return f
+}
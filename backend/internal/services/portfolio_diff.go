@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxPortfolioDiffWindow is how far back a diff goes, as long as holdings snapshots are kept
+	MaxPortfolioDiffWindow = 30 * 24 * time.Hour
+	// maxDiffTriggers caps how many alert triggers a diff lists
+	maxDiffTriggers = 50
+)
+
+// PortfolioDiffService compares a wallet's holdings with an earlier snapshot, so a
+// "what changed" panel doesn't have to diff balances itself
+type PortfolioDiffService struct {
+	walletRepo    repos.WalletRepository
+	valuationRepo repos.WalletValuationRepository
+	alertRepo     repos.AlertRepository
+	positionRepo  repos.DerivativePositionRepository
+	clock         clock.Clock
+}
+
+func NewPortfolioDiffService(walletRepo repos.WalletRepository, valuationRepo repos.WalletValuationRepository, alertRepo repos.AlertRepository, positionRepo repos.DerivativePositionRepository) *PortfolioDiffService {
+	return &PortfolioDiffService{
+		walletRepo:    walletRepo,
+		valuationRepo: valuationRepo,
+		alertRepo:     alertRepo,
+		positionRepo:  positionRepo,
+		clock:         clock.System,
+	}
+}
+
+// Diff returns what changed in the user's wallet at address, on chainID or on every
+// mainnet chain it's tracked on, over the window: holdings against the latest snapshot
+// taken by then, positions opened and the alerts triggered since. Without a snapshot
+// that old there is no baseline, and only the current value is set.
+func (s *PortfolioDiffService) Diff(ctx context.Context, userID uuid.UUID, address string, chainID *int, window time.Duration) (*models.PortfolioDiff, error) {
+	if window <= 0 || window > MaxPortfolioDiffWindow {
+		return nil, errors.BadRequest(fmt.Sprintf("since must be between 1h and %dd", int(MaxPortfolioDiffWindow.Hours()/24)))
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	var walletIDs []uuid.UUID
+	for _, wallet := range wallets {
+		if strings.EqualFold(wallet.Address, address) && !wallet.IsTestnet && (chainID == nil || wallet.ChainID == *chainID) {
+			walletIDs = append(walletIDs, wallet.ID)
+		}
+	}
+	if len(walletIDs) == 0 {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	since := s.clock.Now().Add(-window).UTC()
+	baselineAt, before, err := s.valuationRepo.GetHoldingsAt(ctx, walletIDs, since)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	after, err := s.valuationRepo.GetCurrentHoldings(ctx, walletIDs)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	diff := &models.PortfolioDiff{
+		Address:         address,
+		ChainID:         chainID,
+		Since:           since,
+		BaselineAt:      baselineAt,
+		TokensAdded:     []models.HoldingChange{},
+		TokensRemoved:   []models.HoldingChange{},
+		BalanceChanges:  []models.HoldingChange{},
+		PriceChanges:    []models.HoldingChange{},
+		NewPositions:    []*models.DerivativePosition{},
+		TriggeredAlerts: []models.TriggeredAlert{},
+	}
+	if baselineAt == nil {
+		for _, holding := range after {
+			diff.ValueAfterUSD += holding.ValueUSD
+		}
+		diff.ValueBeforeUSD = diff.ValueAfterUSD
+	} else {
+		diffHoldings(diff, before, after)
+	}
+
+	positions, err := s.positionRepo.GetOpenByAddresses(ctx, []string{address})
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	for _, position := range positions {
+		if position.OpenedAt.Before(since) {
+			continue
+		}
+		if chainID != nil && (position.ChainID == nil || *position.ChainID != *chainID) {
+			continue
+		}
+		diff.NewPositions = append(diff.NewPositions, position)
+	}
+
+	triggers, err := s.alertRepo.GetTriggersSince(ctx, userID, since, maxDiffTriggers)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	tokens := make(map[string]bool, len(before)+len(after))
+	for _, holding := range append(before, after...) {
+		tokens[holdingKey(holding.ChainID, holding.TokenAddress)] = true
+	}
+	for _, trigger := range triggers {
+		if triggerConcernsWallet(trigger.Target, address, chainID, tokens) {
+			diff.TriggeredAlerts = append(diff.TriggeredAlerts, trigger)
+		}
+	}
+
+	return diff, nil
+}
+
+// diffHoldings sorts each token's change into the diff's lists, largest value change
+// first, and sums the value changes
+func diffHoldings(diff *models.PortfolioDiff, before, after []*models.WalletHolding) {
+	previous := make(map[string]*models.WalletHolding, len(before))
+	for _, holding := range before {
+		previous[holdingKey(holding.ChainID, holding.TokenAddress)] = holding
+	}
+
+	seen := make(map[string]bool, len(after))
+	for _, holding := range after {
+		key := holdingKey(holding.ChainID, holding.TokenAddress)
+		seen[key] = true
+		old, held := previous[key]
+		change := holdingChange(old, holding)
+		switch {
+		case !held:
+			diff.TokensAdded = append(diff.TokensAdded, change)
+		case old.Balance != holding.Balance:
+			diff.BalanceChanges = append(diff.BalanceChanges, change)
+		case change.ValueChangeUSD != 0:
+			diff.PriceChanges = append(diff.PriceChanges, change)
+		}
+		addHoldingChange(diff, change)
+	}
+	for _, holding := range before {
+		if !seen[holdingKey(holding.ChainID, holding.TokenAddress)] {
+			change := holdingChange(holding, nil)
+			diff.TokensRemoved = append(diff.TokensRemoved, change)
+			addHoldingChange(diff, change)
+		}
+	}
+
+	for _, changes := range [][]models.HoldingChange{diff.TokensAdded, diff.TokensRemoved, diff.BalanceChanges, diff.PriceChanges} {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return math.Abs(changes[i].ValueChangeUSD) > math.Abs(changes[j].ValueChangeUSD)
+		})
+	}
+}
+
+func addHoldingChange(diff *models.PortfolioDiff, change models.HoldingChange) {
+	diff.ValueBeforeUSD += change.ValueBeforeUSD
+	diff.ValueAfterUSD += change.ValueAfterUSD
+	diff.ValueChangeUSD += change.ValueChangeUSD
+	diff.QuantityEffectUSD += change.QuantityEffectUSD
+	diff.PriceEffectUSD += change.PriceEffectUSD
+}
+
+// holdingChange compares a holding before and after; either may be nil when the token
+// wasn't held. A token without a price on one side is valued at its price on the other,
+// so a token bought or sold outright changes by quantity only.
+func holdingChange(before, after *models.WalletHolding) models.HoldingChange {
+	var change models.HoldingChange
+	var amountBefore, amountAfter float64
+	var priceBefore, priceAfter *float64
+	for _, h := range []*models.WalletHolding{before, after} {
+		if h != nil {
+			change.ChainID, change.TokenAddress, change.Symbol = h.ChainID, h.TokenAddress, h.Symbol
+		}
+	}
+	change.BalanceBefore, change.BalanceAfter = "0", "0"
+	if before != nil {
+		change.BalanceBefore, change.PriceBeforeUSD, change.ValueBeforeUSD = before.Balance, before.PriceUSD, before.ValueUSD
+		amountBefore, priceBefore = before.Amount, holdingPrice(before)
+	}
+	if after != nil {
+		change.BalanceAfter, change.PriceAfterUSD, change.ValueAfterUSD = after.Balance, after.PriceUSD, after.ValueUSD
+		amountAfter, priceAfter = after.Amount, holdingPrice(after)
+	}
+	change.AmountChange = amountAfter - amountBefore
+	change.ValueChangeUSD = change.ValueAfterUSD - change.ValueBeforeUSD
+
+	if priceBefore == nil {
+		priceBefore = priceAfter
+	}
+	if priceBefore == nil {
+		// Nothing to split by; the value is the stored one on both sides
+		change.QuantityEffectUSD = change.ValueChangeUSD
+		return change
+	}
+	change.QuantityEffectUSD = change.AmountChange * *priceBefore
+	change.PriceEffectUSD = change.ValueChangeUSD - change.QuantityEffectUSD
+	return change
+}
+
+// holdingPrice is the price the holding was valued at, which is its value per token
+// when it has no price of its own
+func holdingPrice(h *models.WalletHolding) *float64 {
+	if h.PriceUSD != nil {
+		return h.PriceUSD
+	}
+	if h.Amount > 0 && h.ValueUSD > 0 {
+		price := h.ValueUSD / h.Amount
+		return &price
+	}
+	return nil
+}
+
+func holdingKey(chainID int, tokenAddress string) string {
+	return fmt.Sprintf("%d:%s", chainID, strings.ToLower(tokenAddress))
+}
+
+// triggerConcernsWallet reports whether an alert's target is the wallet, the user's
+// whole portfolio, or a token the wallet holds or held
+func triggerConcernsWallet(target models.AlertTarget, address string, chainID *int, tokens map[string]bool) bool {
+	switch target.Type {
+	case models.AlertTargetTypePortfolio:
+		return true
+	case "address":
+		return strings.EqualFold(target.Identifier, address) && (chainID == nil || target.ChainID == 0 || target.ChainID == *chainID)
+	case "token":
+		return tokens[holdingKey(target.ChainID, target.Identifier)]
+	}
+	return false
+}
\ No newline at end of file
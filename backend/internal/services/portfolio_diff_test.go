@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// diffHoldingsRepo serves one snapshot and the current holdings
+type diffHoldingsRepo struct {
+	repos.WalletValuationRepository
+	baselineAt time.Time
+	before     []*models.WalletHolding
+	after      []*models.WalletHolding
+}
+
+func (r *diffHoldingsRepo) GetHoldingsAt(_ context.Context, _ []uuid.UUID, at time.Time) (*time.Time, []*models.WalletHolding, error) {
+	if at.Before(r.baselineAt) {
+		return nil, nil, nil
+	}
+	return &r.baselineAt, r.before, nil
+}
+
+func (r *diffHoldingsRepo) GetCurrentHoldings(context.Context, []uuid.UUID) ([]*models.WalletHolding, error) {
+	return r.after, nil
+}
+
+type diffAlerts struct {
+	repos.AlertRepository
+	triggers []models.TriggeredAlert
+}
+
+func (a *diffAlerts) GetTriggersSince(context.Context, uuid.UUID, time.Time, int) ([]models.TriggeredAlert, error) {
+	return a.triggers, nil
+}
+
+type diffPositions struct {
+	repos.DerivativePositionRepository
+	positions []*models.DerivativePosition
+}
+
+func (p *diffPositions) GetOpenByAddresses(context.Context, []string) ([]*models.DerivativePosition, error) {
+	return p.positions, nil
+}
+
+func TestPortfolioDiff(t *testing.T) {
+	const (
+		address = "0x8ba1f109551bd432803012645ac136ddd64dba72"
+		weth    = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+		usdc    = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		pepe    = "0x6982508145454ce325ddbe47a25d4ec3d2311933"
+		link    = "0x514910771af9ca656af840dff83e8264ecf986ca"
+	)
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	price := func(p float64) *float64 { return &p }
+	wallet := &models.Wallet{ID: uuid.New(), UserID: userID, Address: address, ChainID: 1}
+
+	holdings := &diffHoldingsRepo{
+		baselineAt: now.Add(-25 * time.Hour),
+		before: []*models.WalletHolding{
+			{ChainID: 1, TokenAddress: weth, Symbol: "WETH", Balance: "2000000000000000000", Amount: 2, PriceUSD: price(2000), ValueUSD: 4000},
+			{ChainID: 1, TokenAddress: usdc, Symbol: "USDC", Balance: "1000000000", Amount: 1000, PriceUSD: price(1), ValueUSD: 1000},
+			{ChainID: 1, TokenAddress: link, Symbol: "LINK", Balance: "10000000000000000000", Amount: 10, PriceUSD: price(10), ValueUSD: 100},
+		},
+		after: []*models.WalletHolding{
+			// One more WETH, and the price went up
+			{ChainID: 1, TokenAddress: weth, Symbol: "WETH", Balance: "3000000000000000000", Amount: 3, PriceUSD: price(2100), ValueUSD: 6300},
+			// Bought without a price; valued as stored
+			{ChainID: 1, TokenAddress: pepe, Symbol: "PEPE", Balance: "5", Amount: 5, ValueUSD: 50},
+			// Held throughout, only the price moved
+			{ChainID: 1, TokenAddress: link, Symbol: "LINK", Balance: "10000000000000000000", Amount: 10, PriceUSD: price(12), ValueUSD: 120},
+		},
+	}
+	alerts := &diffAlerts{triggers: []models.TriggeredAlert{
+		{AlertID: uuid.New(), Type: models.AlertTypePriceAbove, Target: models.AlertTarget{Type: "token", Identifier: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", ChainID: 1}},
+		{AlertID: uuid.New(), Type: models.AlertTypePriceAbove, Target: models.AlertTarget{Type: "token", Identifier: weth, ChainID: 137}},
+		{AlertID: uuid.New(), Type: models.AlertTypeLargeTransfer, Target: models.AlertTarget{Type: "address", Identifier: address, ChainID: 1}},
+		{AlertID: uuid.New(), Type: models.AlertTypePortfolioValue, Target: models.AlertTarget{Type: models.AlertTargetTypePortfolio}},
+		{AlertID: uuid.New(), Type: models.AlertTypeLargeTransfer, Target: models.AlertTarget{Type: "address", Identifier: "0x1111111254eeb25477b68fb85ed929f73a960582", ChainID: 1}},
+	}}
+	arbitrum := 42161
+	positions := &diffPositions{positions: []*models.DerivativePosition{
+		{Venue: "gmx_v2", ChainID: &arbitrum, OpenedAt: now.Add(-time.Hour)},
+		{Venue: "hyperliquid", OpenedAt: now.Add(-48 * time.Hour)},
+	}}
+
+	service := NewPortfolioDiffService(&allowanceWallets{wallets: []*models.Wallet{wallet}}, holdings, alerts, positions)
+	service.clock = clock.NewFake(now)
+
+	diff, err := service.Diff(ctx, userID, address, nil, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-24*time.Hour), diff.Since)
+	assert.Equal(t, holdings.baselineAt, *diff.BaselineAt)
+
+	require.Len(t, diff.BalanceChanges, 1)
+	ethChange := diff.BalanceChanges[0]
+	assert.Equal(t, "WETH", ethChange.Symbol)
+	assert.Equal(t, 1.0, ethChange.AmountChange)
+	assert.Equal(t, 2300.0, ethChange.ValueChangeUSD)
+	assert.Equal(t, 2000.0, ethChange.QuantityEffectUSD)
+	assert.Equal(t, 300.0, ethChange.PriceEffectUSD)
+
+	require.Len(t, diff.TokensAdded, 1)
+	assert.Equal(t, "0", diff.TokensAdded[0].BalanceBefore)
+	assert.Equal(t, 50.0, diff.TokensAdded[0].QuantityEffectUSD)
+	assert.Zero(t, diff.TokensAdded[0].PriceEffectUSD)
+	require.Len(t, diff.TokensRemoved, 1)
+	assert.Equal(t, "USDC", diff.TokensRemoved[0].Symbol)
+	assert.Equal(t, -1000.0, diff.TokensRemoved[0].QuantityEffectUSD)
+	require.Len(t, diff.PriceChanges, 1)
+	assert.Equal(t, 20.0, diff.PriceChanges[0].PriceEffectUSD)
+
+	assert.Equal(t, 5100.0, diff.ValueBeforeUSD)
+	assert.Equal(t, 6470.0, diff.ValueAfterUSD)
+	assert.Equal(t, 1370.0, diff.ValueChangeUSD)
+	assert.Equal(t, 1050.0, diff.QuantityEffectUSD)
+	assert.Equal(t, 320.0, diff.PriceEffectUSD)
+
+	// The WETH, wallet and portfolio alerts, not WETH on Polygon or someone else's wallet
+	require.Len(t, diff.TriggeredAlerts, 3)
+	assert.Equal(t, alerts.triggers[:1], diff.TriggeredAlerts[:1])
+	require.Len(t, diff.NewPositions, 1)
+	assert.Equal(t, "gmx_v2", diff.NewPositions[0].Venue)
+
+	// Without a snapshot that old, only the current value is known
+	diff, err = service.Diff(ctx, userID, address, nil, 30*time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, diff.BaselineAt)
+	assert.Empty(t, diff.TokensAdded)
+	assert.Equal(t, 6470.0, diff.ValueAfterUSD)
+	assert.Zero(t, diff.ValueChangeUSD)
+
+	// Positions elsewhere are left out on one chain
+	ethereum := 1
+	diff, err = service.Diff(ctx, userID, address, &ethereum, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, diff.NewPositions)
+
+	_, err = service.Diff(ctx, uuid.New(), address, nil, 24*time.Hour)
+	assert.Equal(t, 404, err.(*errors.AppError).Status)
+	_, err = service.Diff(ctx, userID, address, nil, 31*24*time.Hour)
+	assert.Equal(t, 400, err.(*errors.AppError).Status)
+}
\ No newline at end of file
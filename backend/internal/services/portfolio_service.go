@@ -2,31 +2,64 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
 	"github.com/defi-dashboard/backend/pkg/logger"
 )
 
 type PortfolioService struct {
-	walletRepo repos.WalletRepository
-	tokenRepo  repos.TokenRepository
+	walletRepo        repos.WalletRepository
+	tokenRepo         repos.TokenRepository
+	tokenMetadataRepo repos.TokenMetadataRepository
+	derivativeRepo    repos.DerivativePositionRepository
+	esploraClient     *external.EsploraClient
+	clock             clock.Clock
 }
 
-func NewPortfolioService(walletRepo repos.WalletRepository, tokenRepo repos.TokenRepository) *PortfolioService {
+func NewPortfolioService(walletRepo repos.WalletRepository, tokenRepo repos.TokenRepository, tokenMetadataRepo repos.TokenMetadataRepository, derivativeRepo repos.DerivativePositionRepository, esploraClient *external.EsploraClient) *PortfolioService {
 	return &PortfolioService{
-		walletRepo: walletRepo,
-		tokenRepo:  tokenRepo,
+		walletRepo:        walletRepo,
+		tokenRepo:         tokenRepo,
+		tokenMetadataRepo: tokenMetadataRepo,
+		derivativeRepo:    derivativeRepo,
+		esploraClient:     esploraClient,
+		clock:             clock.System,
 	}
 }
 
-// GetBalances returns real token balances for an address from blockchain
-func (s *PortfolioService) GetBalances(ctx context.Context, address string, chainID *int, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error) {
+// BalancePin asks for all of a chain's balances to be read at one block, for auditors
+// who need them consistent with one another. Block defaults to the chain head.
+type BalancePin struct {
+	Block *int64
+}
+
+// GetBalances returns real token balances for an address from blockchain, annotated with
+// the block they reflect. With a pin they are all read at that block, on EVM chains only.
+func (s *PortfolioService) GetBalances(ctx context.Context, address string, chainID *int, hideSmall bool, pin *BalancePin, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error) {
 	logger.Info("Fetching portfolio balances", "address", address, "chainID", chainID)
 
+	// Accounts outside the EVM are read by their chain's namespace
+	if network, ok := blockchain.AccountChain(address); ok {
+		reader, ok := s.networkReaders()[network.Namespace]
+		if !ok {
+			return nil, errors.BadRequest("Unsupported network: " + network.String())
+		}
+		if pin != nil {
+			return nil, errors.BadRequest("Pinned balances are only supported on EVM chains")
+		}
+		return reader.balances(ctx, address, network, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+	}
+
 	// Default to Ethereum mainnet if no chain specified
 	chain := 1
 	if chainID != nil {
@@ -51,26 +84,42 @@ func (s *PortfolioService) GetBalances(ctx context.Context, address string, chai
 	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, coinGeckoAPIKey)
 	
 	// Get real balances from blockchain
-	balances, totalValue, err := blockchainService.GetWalletBalances(ctx, address, chain)
+	var (
+		balances   []*models.Balance
+		totalValue float64
+		block      *models.DataBlock
+		err        error
+	)
+	if pin != nil {
+		// The block is fixed first so every balance is read at it
+		var header *blockchain.BlockHeader
+		header, err = blockchainService.GetBlock(ctx, chain, pin.Block)
+		if stderrors.Is(err, blockchain.ErrBlockNotFound) {
+			return nil, errors.BadRequest("Block not found")
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read block: %w", err)
+		}
+		block = dataBlock(chain, header, true)
+		balances, totalValue, err = blockchainService.GetWalletBalancesAtBlock(ctx, address, chain, header.Number)
+	} else {
+		balances, totalValue, err = blockchainService.GetWalletBalances(ctx, address, chain)
+	}
 	if err != nil {
 		logger.Error("Failed to fetch wallet balances", "error", err, "address", address, "chainID", chain)
 		return nil, fmt.Errorf("failed to fetch wallet balances: %w", err)
 	}
+	if block == nil {
+		// The head right after the reads is the closest block they can be tied to
+		if header, err := blockchainService.GetBlock(ctx, chain, nil); err != nil {
+			logger.Warn("Failed to read the head block for the balances", "error", err, "chainID", chain)
+		} else {
+			block = dataBlock(chain, header, false)
+		}
+	}
 
 	// Filter small balances if requested
 	if hideSmall {
-		filteredBalances := make([]*models.Balance, 0)
-		filteredTotalValue := 0.0
-
-		for _, balance := range balances {
-			if balance.BalanceUSD != nil && *balance.BalanceUSD >= 1.0 {
-				filteredBalances = append(filteredBalances, balance)
-				filteredTotalValue += *balance.BalanceUSD
-			}
-		}
-
-		balances = filteredBalances
-		totalValue = filteredTotalValue
+		balances, totalValue = filterSmallBalances(balances)
 	}
 
 	// Store/update balances in database (optional - for caching)
@@ -85,12 +134,156 @@ func (s *PortfolioService) GetBalances(ctx context.Context, address string, chai
 		"tokenCount", len(balances), 
 		"totalValue", totalValue)
 
+	assignAssets(balances)
+	portfolio := &PortfolioBalances{
+		TotalValue: totalValue,
+		Balances:   balances,
+		IsTestnet:  blockchain.IsTestnet(chain),
+		Block:      block,
+	}
+
+	// Perpetual positions aren't held on one chain, so only the unfiltered view includes them
+	if chainID == nil {
+		portfolio.Derivatives, portfolio.DerivativesValue = s.getDerivatives(ctx, address)
+		portfolio.TotalValue += portfolio.DerivativesValue
+	}
+
+	return portfolio, nil
+}
+
+// dataBlock annotates balances with the block they were read at
+func dataBlock(chainID int, header *blockchain.BlockHeader, pinned bool) *models.DataBlock {
+	return &models.DataBlock{
+		ChainID:        chainID,
+		BlockNumber:    header.Number,
+		BlockHash:      header.Hash,
+		BlockTimestamp: header.Timestamp,
+		Pinned:         pinned,
+	}
+}
+
+// networkReader reads an account's balances on the chains of one CAIP-2 namespace outside
+// the EVM: on the account's own chain, and on every chain of the namespace it can hold
+// assets on
+type networkReader struct {
+	balances func(ctx context.Context, address string, chain models.ChainRef, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error)
+	all      func(ctx context.Context, address string, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*MultiChainPortfolio, error)
+}
+
+// networkReaders are the readers of the namespaces supported besides the EVM's, by namespace
+func (s *PortfolioService) networkReaders() map[string]networkReader {
+	return map[string]networkReader{
+		models.ChainNamespaceCosmos: {
+			balances: func(ctx context.Context, address string, chain models.ChainRef, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error) {
+				cosmosChain, _ := blockchain.CosmosChainForAddress(address)
+				return s.getCosmosBalances(ctx, address, cosmosChain, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+			},
+			all: func(ctx context.Context, address string, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*MultiChainPortfolio, error) {
+				return s.getMultiCosmosBalances(ctx, address, hideSmall, alchemyAPIKey, coinGeckoAPIKey), nil
+			},
+		},
+		models.ChainNamespaceBitcoin: {
+			balances: func(ctx context.Context, address string, _ models.ChainRef, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error) {
+				return s.getBitcoinBalances(ctx, address, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+			},
+			all: s.getMultiBitcoinBalances,
+		},
+	}
+}
+
+// getCosmosBalances returns an address's balances on a Cosmos SDK chain, with its
+// delegations and unclaimed staking rewards counted towards the total
+func (s *PortfolioService) getCosmosBalances(ctx context.Context, address string, chain blockchain.CosmosChain, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error) {
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, coinGeckoAPIKey)
+
+	holdings, err := blockchainService.GetCosmosHoldings(ctx, address, chain)
+	if err != nil {
+		logger.Error("Failed to fetch Cosmos holdings", "error", err, "address", address, "chain", chain.ChainID)
+		return nil, fmt.Errorf("failed to fetch wallet balances: %w", err)
+	}
+
+	balances, balancesValue := holdings.Balances, holdings.BalancesValue
+	if hideSmall {
+		balances, balancesValue = filterSmallBalances(balances)
+	}
+	if balances == nil {
+		balances = []*models.Balance{}
+	}
+
+	assignAssets(balances)
+	ref := holdings.Chain
+	return &PortfolioBalances{
+		TotalValue:  holdings.TotalValue - holdings.BalancesValue + balancesValue,
+		Balances:    balances,
+		Chain:       &ref,
+		Delegations: holdings.Delegations,
+	}, nil
+}
+
+// getBitcoinBalances returns the BTC balance of a Bitcoin address or of every address an
+// extended public key has used
+func (s *PortfolioService) getBitcoinBalances(ctx context.Context, identifier string, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*PortfolioBalances, error) {
+	state, err := blockchain.ScanBitcoinAccount(ctx, s.esploraClient, identifier)
+	if err != nil {
+		logger.Error("Failed to fetch Bitcoin balance", "error", err)
+		return nil, fmt.Errorf("failed to fetch wallet balances: %w", err)
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, coinGeckoAPIKey)
+	var price *float64
+	if p, err := blockchainService.GetBTCPriceUSD(ctx); err != nil {
+		logger.Warn("Failed to get BTC price, balance is reported unvalued", "error", err)
+	} else {
+		price = &p
+	}
+
+	balances := []*models.Balance{}
+	totalValue := 0.0
+	if state.BalanceSats > 0 {
+		balance := blockchain.BitcoinBalance(state.BalanceSats, price)
+		balances = append(balances, balance)
+		if balance.BalanceUSD != nil {
+			totalValue = *balance.BalanceUSD
+		}
+	}
+	if hideSmall {
+		balances, totalValue = filterSmallBalances(balances)
+	}
+
+	assignAssets(balances)
+	chain := models.BitcoinChain
 	return &PortfolioBalances{
 		TotalValue: totalValue,
 		Balances:   balances,
+		Chain:      &chain,
 	}, nil
 }
 
+// filterSmallBalances drops balances worth less than $1, returning the rest with their total
+func filterSmallBalances(balances []*models.Balance) ([]*models.Balance, float64) {
+	filteredBalances := make([]*models.Balance, 0)
+	filteredTotalValue := 0.0
+
+	for _, balance := range balances {
+		if balance.BalanceUSD != nil && *balance.BalanceUSD >= 1.0 {
+			filteredBalances = append(filteredBalances, balance)
+			filteredTotalValue += *balance.BalanceUSD
+		}
+	}
+
+	return filteredBalances, filteredTotalValue
+}
+
+// assignAssets maps each balance's token into the chain-agnostic asset model
+func assignAssets(balances []*models.Balance) {
+	for _, balance := range balances {
+		if balance.Token != nil {
+			asset := balance.Token.Asset()
+			balance.Asset = &asset
+		}
+	}
+}
+
 // GetHistory returns portfolio value history (currently mock - real implementation would need historical data)
 func (s *PortfolioService) GetHistory(ctx context.Context, address string, chainID *int, period string, interval string, alchemyAPIKey, coinGeckoAPIKey string) ([]*PortfolioHistoryPoint, error) {
 	logger.Info("Fetching portfolio history", "address", address, "period", period)
@@ -99,7 +292,7 @@ func (s *PortfolioService) GetHistory(ctx context.Context, address string, chain
 	// For now, we generate mock history based on current portfolio value
 	
 	// Get current portfolio value
-	currentBalances, err := s.GetBalances(ctx, address, chainID, false, alchemyAPIKey, coinGeckoAPIKey)
+	currentBalances, err := s.GetBalances(ctx, address, chainID, false, nil, alchemyAPIKey, coinGeckoAPIKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current balances for history: %w", err)
 	}
@@ -110,7 +303,7 @@ func (s *PortfolioService) GetHistory(ctx context.Context, address string, chain
 	}
 
 	// Determine time range based on period
-	endTime := time.Now()
+	endTime := s.clock.Now()
 	var startTime time.Time
 	var points int
 
@@ -198,21 +391,44 @@ func (s *PortfolioService) storeBalances(ctx context.Context, address string, ch
 	return nil
 }
 
-// GetMultiChainBalances gets balances across multiple chains
-func (s *PortfolioService) GetMultiChainBalances(ctx context.Context, address string, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*MultiChainPortfolio, error) {
+// GetMultiChainBalances gets balances across multiple chains. Testnets are left out of
+// the chains and the total unless includeTestnets is set. When pinned, each chain's
+// balances are read at that chain's head when its read starts.
+func (s *PortfolioService) GetMultiChainBalances(ctx context.Context, address string, hideSmall, includeTestnets, pinned bool, alchemyAPIKey, coinGeckoAPIKey string) (*MultiChainPortfolio, error) {
 	logger.Info("Fetching multi-chain portfolio", "address", address)
 
-	supportedChains := blockchain.GetSupportedChains()
+	// Accounts outside the EVM are read across their namespace's chains
+	if network, ok := blockchain.AccountChain(address); ok {
+		reader, ok := s.networkReaders()[network.Namespace]
+		if !ok {
+			return nil, errors.BadRequest("Unsupported network: " + network.String())
+		}
+		if pinned {
+			return nil, errors.BadRequest("Pinned balances are only supported on EVM chains")
+		}
+		return reader.all(ctx, address, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+	}
+
+	var pin *BalancePin
+	if pinned {
+		pin = &BalancePin{}
+	}
+
+	supportedChains := blockchain.SupportedChainsFor(includeTestnets)
 	chainBalances := make(map[int]*PortfolioBalances)
 	totalValue := 0.0
+	var blocks []*models.DataBlock
 
 	for _, chainID := range supportedChains {
-		balances, err := s.GetBalances(ctx, address, &chainID, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+		balances, err := s.GetBalances(ctx, address, &chainID, hideSmall, pin, alchemyAPIKey, coinGeckoAPIKey)
 		if err != nil {
 			logger.Error("Failed to get balances for chain", "chainID", chainID, "error", err)
 			// Continue with other chains
 			continue
 		}
+		if balances.Block != nil {
+			blocks = append(blocks, balances.Block)
+		}
 
 		if balances.TotalValue > 0 {
 			chainBalances[chainID] = balances
@@ -220,17 +436,158 @@ func (s *PortfolioService) GetMultiChainBalances(ctx context.Context, address st
 		}
 	}
 
+	derivatives, derivativesValue := s.getDerivatives(ctx, address)
+
 	return &MultiChainPortfolio{
-		TotalValue:    totalValue,
-		ChainBalances: chainBalances,
+		TotalValue:       totalValue + derivativesValue,
+		ChainBalances:    chainBalances,
+		Blocks:           blocks,
+		Derivatives:      derivatives,
+		DerivativesValue: derivativesValue,
 	}, nil
 }
 
+// getMultiCosmosBalances gets a Cosmos address's balances on every supported Cosmos SDK
+// chain, keyed by CAIP-2 chain identifier
+func (s *PortfolioService) getMultiCosmosBalances(ctx context.Context, address string, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) *MultiChainPortfolio {
+	portfolio := &MultiChainPortfolio{
+		ChainBalances:   make(map[int]*PortfolioBalances),
+		NetworkBalances: make(map[string]*PortfolioBalances),
+	}
+
+	for _, chain := range blockchain.GetCosmosChains() {
+		chainAddress, err := blockchain.CosmosAddressOn(address, chain)
+		if err != nil {
+			logger.Error("Failed to derive Cosmos address", "chain", chain.ChainID, "error", err)
+			continue
+		}
+
+		balances, err := s.getCosmosBalances(ctx, chainAddress, chain, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+		if err != nil {
+			logger.Error("Failed to get balances for chain", "chain", chain.ChainID, "error", err)
+			// Continue with other chains
+			continue
+		}
+
+		if balances.TotalValue > 0 {
+			portfolio.NetworkBalances[chain.Ref().String()] = balances
+			portfolio.TotalValue += balances.TotalValue
+		}
+	}
+
+	return portfolio
+}
+
+// getMultiBitcoinBalances returns a Bitcoin account's balance as its only network's
+func (s *PortfolioService) getMultiBitcoinBalances(ctx context.Context, identifier string, hideSmall bool, alchemyAPIKey, coinGeckoAPIKey string) (*MultiChainPortfolio, error) {
+	balances, err := s.getBitcoinBalances(ctx, identifier, hideSmall, alchemyAPIKey, coinGeckoAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiChainPortfolio{
+		TotalValue:      balances.TotalValue,
+		ChainBalances:   make(map[int]*PortfolioBalances),
+		NetworkBalances: map[string]*PortfolioBalances{models.BitcoinChain.String(): balances},
+	}, nil
+}
+
+// getDerivatives returns the address's open perpetual positions as last synced, with their
+// combined equity. They are an addition to the portfolio, so failures only log.
+func (s *PortfolioService) getDerivatives(ctx context.Context, address string) ([]*models.DerivativePosition, float64) {
+	positions, err := s.derivativeRepo.GetOpenByAddresses(ctx, []string{address})
+	if err != nil {
+		logger.Error("Failed to load derivative positions", "error", err, "address", address)
+		return nil, 0
+	}
+
+	value := 0.0
+	for _, position := range positions {
+		value += position.EquityUSD()
+	}
+	return positions, value
+}
+
+// GetSectorAllocation groups an address's holdings by the primary CoinGecko category of each token
+func (s *PortfolioService) GetSectorAllocation(ctx context.Context, address string, chainID *int, alchemyAPIKey, coinGeckoAPIKey string) ([]*models.SectorAllocation, error) {
+	portfolio, err := s.GetBalances(ctx, address, chainID, true, nil, alchemyAPIKey, coinGeckoAPIKey)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := 1
+	if chainID != nil {
+		chain = *chainID
+	}
+
+	addresses := make([]string, 0, len(portfolio.Balances))
+	for _, balance := range portfolio.Balances {
+		if balance.Token != nil {
+			addresses = append(addresses, balance.Token.Address)
+		}
+	}
+
+	metadata, err := s.tokenMetadataRepo.GetByAddresses(ctx, chain, addresses)
+	if err != nil {
+		logger.Warn("Failed to load token metadata, reporting holdings as uncategorized", "error", err)
+		metadata = nil
+	}
+
+	return sectorAllocation(portfolio.Balances, metadata), nil
+}
+
+// sectorAllocation sums balance values per sector, largest sector first
+func sectorAllocation(balances []*models.Balance, metadata map[string]*models.TokenMetadata) []*models.SectorAllocation {
+	sectors := make(map[string]*models.SectorAllocation)
+	total := 0.0
+
+	for _, balance := range balances {
+		if balance.Token == nil || balance.BalanceUSD == nil || *balance.BalanceUSD <= 0 {
+			continue
+		}
+
+		sector := metadata[strings.ToLower(balance.Token.Address)].Sector()
+		allocation, ok := sectors[sector]
+		if !ok {
+			allocation = &models.SectorAllocation{Sector: sector}
+			sectors[sector] = allocation
+		}
+		allocation.ValueUSD += *balance.BalanceUSD
+		allocation.Tokens = append(allocation.Tokens, balance.Token.Symbol)
+		total += *balance.BalanceUSD
+	}
+
+	result := make([]*models.SectorAllocation, 0, len(sectors))
+	for _, allocation := range sectors {
+		if total > 0 {
+			allocation.Percentage = allocation.ValueUSD / total * 100
+		}
+		result = append(result, allocation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ValueUSD != result[j].ValueUSD {
+			return result[i].ValueUSD > result[j].ValueUSD
+		}
+		return result[i].Sector < result[j].Sector
+	})
+
+	return result
+}
+
 // Response types
 
 type PortfolioBalances struct {
 	TotalValue float64           `json:"total_value"`
 	Balances   []*models.Balance `json:"balances"`
+	// IsTestnet marks balances on a testnet, which have no real value
+	IsTestnet bool `json:"is_testnet,omitempty"`
+	// Chain and Delegations are set for non-EVM chains
+	Chain       *models.ChainRef           `json:"chain,omitempty"`
+	Delegations []*models.CosmosDelegation `json:"delegations,omitempty"`
+	// Derivatives are open perpetual positions, valued at their equity in DerivativesValue
+	Derivatives      []*models.DerivativePosition `json:"derivatives,omitempty"`
+	DerivativesValue float64                      `json:"derivatives_value,omitempty"`
+	// Block is the block the balances reflect, set on EVM chains
+	Block *models.DataBlock `json:"block,omitempty"`
 }
 
 type PortfolioHistoryPoint struct {
@@ -239,8 +596,15 @@ type PortfolioHistoryPoint struct {
 }
 
 type MultiChainPortfolio struct {
-	TotalValue    float64                     `json:"total_value"`
-	ChainBalances map[int]*PortfolioBalances  `json:"chain_balances"`
+	TotalValue    float64                    `json:"total_value"`
+	ChainBalances map[int]*PortfolioBalances `json:"chain_balances"`
+	// NetworkBalances are balances on non-EVM chains, keyed by CAIP-2 chain identifier
+	NetworkBalances map[string]*PortfolioBalances `json:"network_balances,omitempty"`
+	// Blocks are the blocks each EVM chain's balances reflect, including chains left out
+	// of ChainBalances for holding nothing
+	Blocks           []*models.DataBlock          `json:"blocks,omitempty"`
+	Derivatives      []*models.DerivativePosition  `json:"derivatives,omitempty"`
+	DerivativesValue float64                       `json:"derivatives_value,omitempty"`
 }
 
 type Allocation struct {
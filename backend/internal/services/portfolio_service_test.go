@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSectorAllocation(t *testing.T) {
+	balance := func(address, symbol string, value float64) *models.Balance {
+		return &models.Balance{
+			Token:      &models.Token{Address: address, Symbol: symbol},
+			BalanceUSD: utils.Float64Ptr(value),
+		}
+	}
+	balances := []*models.Balance{
+		balance("0xA0b8", "USDC", 500),
+		balance("0x6B17", "DAI", 250),
+		balance("0x1f98", "UNI", 200),
+		balance("0xdead", "MEME", 50),
+		balance("0xbeef", "DUST", 0),
+	}
+	metadata := map[string]*models.TokenMetadata{
+		"0xa0b8": {Categories: []string{"Stablecoins", "USD Stablecoin"}},
+		"0x6b17": {Categories: []string{"Stablecoins"}},
+		"0x1f98": {Categories: []string{"Decentralized Exchange (DEX)"}},
+	}
+
+	sectors := sectorAllocation(balances, metadata)
+	require.Len(t, sectors, 3)
+
+	assert.Equal(t, "Stablecoins", sectors[0].Sector)
+	assert.Equal(t, 750.0, sectors[0].ValueUSD)
+	assert.InDelta(t, 75.0, sectors[0].Percentage, 0.001)
+	assert.Equal(t, []string{"USDC", "DAI"}, sectors[0].Tokens)
+
+	assert.Equal(t, "Decentralized Exchange (DEX)", sectors[1].Sector)
+	assert.Equal(t, models.SectorUncategorized, sectors[2].Sector)
+	assert.InDelta(t, 5.0, sectors[2].Percentage, 0.001)
+}
+
+func TestPinnedBalancesRequireEVM(t *testing.T) {
+	service := &PortfolioService{}
+	ctx := context.Background()
+
+	// Only EVM state can be read at a past block
+	_, err := service.GetBalances(ctx, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", nil, false, &BalancePin{}, "", "")
+	require.Error(t, err)
+	assert.Equal(t, 400, err.(*errors.AppError).Status)
+
+	_, err = service.GetMultiChainBalances(ctx, "cosmos1hsk6jryyqjfhp5dhc55tc9jtckygx0eph6dd02", false, false, true, "", "")
+	require.Error(t, err)
+	assert.Equal(t, 400, err.(*errors.AppError).Status)
+}
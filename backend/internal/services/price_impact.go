@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// quoteShortfallTolerance is how far (percent) an aggregator quote may fall below the
+// best direct pool before it is flagged; routing splits usually beat single pools
+const quoteShortfallTolerance = 1.0
+
+type PriceImpactRequest struct {
+	ChainID    int    `json:"chainId"`
+	FromToken  string `json:"fromToken"`
+	ToToken    string `json:"toToken"`
+	FromAmount string `json:"fromAmount"`
+	// QuotedToAmount is an optional aggregator quote to validate against on-chain liquidity
+	QuotedToAmount string `json:"quotedToAmount,omitempty"`
+}
+
+type PoolPriceImpact struct {
+	Venue       string  `json:"venue"`
+	Pool        string  `json:"pool"`
+	FeeTier     int64   `json:"feeTier,omitempty"`
+	FeePercent  float64 `json:"feePercent"`
+	ToAmount    string  `json:"toAmount"`
+	PriceImpact float64 `json:"priceImpact"`
+	Approximate bool    `json:"approximate"`
+}
+
+type PriceImpactEstimate struct {
+	ChainID    int               `json:"chainId"`
+	FromToken  string            `json:"fromToken"`
+	ToToken    string            `json:"toToken"`
+	FromAmount string            `json:"fromAmount"`
+	Best       *PoolPriceImpact  `json:"best"`
+	Pools      []PoolPriceImpact `json:"pools"`
+	// QuoteDeviation is how far (percent) the quoted amount is above (+) or below (-) the best pool
+	QuoteDeviation *float64 `json:"quoteDeviation,omitempty"`
+	QuoteSuspect   bool     `json:"quoteSuspect"`
+}
+
+// EstimatePriceImpact estimates executable price impact for a trade from on-chain pool
+// reserves (Uniswap v2/v3, Curve), independent of aggregators
+func (s *SwapService) EstimatePriceImpact(ctx context.Context, req PriceImpactRequest, alchemyAPIKey string) (*PriceImpactEstimate, error) {
+	amountIn, ok := new(big.Int).SetString(req.FromAmount, 10)
+	if !ok || amountIn.Sign() <= 0 {
+		return nil, errors.BadRequest("FromAmount must be a positive integer in base units")
+	}
+	var quoted *big.Int
+	if req.QuotedToAmount != "" {
+		quoted, ok = new(big.Int).SetString(req.QuotedToAmount, 10)
+		if !ok || quoted.Sign() < 0 {
+			return nil, errors.BadRequest("QuotedToAmount must be a non-negative integer in base units")
+		}
+	}
+	if !blockchain.SupportsPoolQuotes(req.ChainID) {
+		return nil, errors.BadRequest(fmt.Sprintf("Price impact estimates are not supported on chain %d", req.ChainID))
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+	quotes, err := blockchainService.QuotePools(ctx, req.ChainID, req.FromToken, req.ToToken, amountIn)
+	if err != nil {
+		logger.Error("Failed to quote pools", "error", err, "chainID", req.ChainID)
+		return nil, errors.Internal("Failed to estimate price impact")
+	}
+	if len(quotes) == 0 {
+		return nil, errors.NotFound("Direct pool for this token pair")
+	}
+
+	estimate := &PriceImpactEstimate{
+		ChainID:    req.ChainID,
+		FromToken:  req.FromToken,
+		ToToken:    req.ToToken,
+		FromAmount: req.FromAmount,
+		Pools:      poolPriceImpacts(quotes),
+	}
+	estimate.Best = &estimate.Pools[0]
+
+	if quoted != nil {
+		deviation := quoteDeviation(quoted, quotes[0].AmountOut)
+		estimate.QuoteDeviation = &deviation
+		estimate.QuoteSuspect = deviation < -quoteShortfallTolerance
+	}
+
+	return estimate, nil
+}
+
+// poolPriceImpacts sorts quotes best output first (in place) and converts them for the response
+func poolPriceImpacts(quotes []*blockchain.PoolQuote) []PoolPriceImpact {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].AmountOut.Cmp(quotes[j].AmountOut) > 0
+	})
+
+	pools := make([]PoolPriceImpact, len(quotes))
+	for i, q := range quotes {
+		pools[i] = PoolPriceImpact{
+			Venue:       q.Venue,
+			Pool:        q.Pool,
+			FeeTier:     q.FeeTier,
+			FeePercent:  float64(q.Fee) / 10000,
+			ToAmount:    q.AmountOut.String(),
+			PriceImpact: q.PriceImpact,
+			Approximate: q.Approximate,
+		}
+	}
+	return pools
+}
+
+// quoteDeviation is the percent difference of quoted from the best on-chain output
+func quoteDeviation(quoted, best *big.Int) float64 {
+	if best.Sign() == 0 {
+		return 0
+	}
+	diff := new(big.Float).SetInt(new(big.Int).Sub(quoted, best))
+	ratio, _ := diff.Quo(diff, new(big.Float).SetInt(best)).Float64()
+	return ratio * 100
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolPriceImpacts(t *testing.T) {
+	pools := poolPriceImpacts([]*blockchain.PoolQuote{
+		{Venue: blockchain.VenueUniswapV2, Fee: 3000, AmountOut: big.NewInt(990), PriceImpact: 0.7},
+		{Venue: blockchain.VenueUniswapV3, FeeTier: 500, Fee: 500, AmountOut: big.NewInt(998), PriceImpact: 0.1, Approximate: true},
+		{Venue: blockchain.VenueCurve, AmountOut: big.NewInt(995), PriceImpact: 0.2},
+	})
+
+	assert.Equal(t, blockchain.VenueUniswapV3, pools[0].Venue)
+	assert.Equal(t, "998", pools[0].ToAmount)
+	assert.Equal(t, 0.05, pools[0].FeePercent)
+	assert.Equal(t, blockchain.VenueCurve, pools[1].Venue)
+	assert.Equal(t, 0.3, pools[2].FeePercent)
+}
+
+func TestQuoteDeviation(t *testing.T) {
+	assert.InDelta(t, -2.0, quoteDeviation(big.NewInt(980), big.NewInt(1000)), 1e-9)
+	assert.InDelta(t, 1.5, quoteDeviation(big.NewInt(1015), big.NewInt(1000)), 1e-9)
+	assert.Equal(t, 0.0, quoteDeviation(big.NewInt(5), big.NewInt(0)))
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// priceRefreshMinAge is how old a stored price must be before a refresh fetches a new
+	// one; requests inside it get the stored price, so a crowd refreshing after a move
+	// costs one provider call
+	priceRefreshMinAge = 30 * time.Second
+	// priceRefreshTimeout bounds the provider fetch shared by coalesced requests
+	priceRefreshTimeout = 10 * time.Second
+)
+
+// PriceClient fetches current prices by CoinGecko ID
+type PriceClient interface {
+	GetTokenPrices(ctx context.Context, tokenIDs []string) (external.PriceResponse, error)
+}
+
+// RefreshedPrice is a token's price after an on-demand refresh
+type RefreshedPrice struct {
+	TokenID        uuid.UUID `json:"token_id"`
+	PriceUSD       float64   `json:"price_usd"`
+	PriceChange24h float64   `json:"price_change_24h"`
+	LastUpdated    time.Time `json:"last_updated"`
+	// Refreshed is false when a price fetched moments ago was returned instead
+	Refreshed bool `json:"refreshed"`
+}
+
+// PriceRefreshService fetches a token's price on demand, outside the price job's schedule.
+// Concurrent refreshes of the same token share one provider call.
+type PriceRefreshService struct {
+	tokenRepo   repos.TokenMetadataRepository
+	priceClient PriceClient
+	group       singleflight.Group
+	clock       clock.Clock
+}
+
+func NewPriceRefreshService(tokenRepo repos.TokenMetadataRepository, priceClient PriceClient) *PriceRefreshService {
+	return &PriceRefreshService{
+		tokenRepo:   tokenRepo,
+		priceClient: priceClient,
+		clock:       clock.System,
+	}
+}
+
+// RefreshPrice fetches and stores a token's current price, unless it was refreshed
+// within priceRefreshMinAge
+func (s *PriceRefreshService) RefreshPrice(ctx context.Context, tokenID uuid.UUID) (*RefreshedPrice, error) {
+	result, err, _ := s.group.Do(tokenID.String(), func() (interface{}, error) {
+		// The fetch is shared, so one caller going away mustn't cancel it for the rest. Only
+		// the request ID is kept: Fiber recycles the request's context once it's answered.
+		fetchCtx, cancel := context.WithTimeout(correlation.Detach(ctx), priceRefreshTimeout)
+		defer cancel()
+		return s.refresh(fetchCtx, tokenID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	price := *result.(*RefreshedPrice)
+	return &price, nil
+}
+
+func (s *PriceRefreshService) refresh(ctx context.Context, tokenID uuid.UUID) (*RefreshedPrice, error) {
+	token, err := s.tokenRepo.GetToken(ctx, tokenID)
+	if err != nil {
+		if stderrors.Is(err, repos.ErrTokenNotFound) {
+			return nil, errors.NotFound("Token")
+		}
+		return nil, errors.DatabaseError(err)
+	}
+
+	if token.PriceUSD != nil && token.LastUpdated != nil && s.clock.Now().Sub(*token.LastUpdated) < priceRefreshMinAge {
+		price := &RefreshedPrice{TokenID: tokenID, PriceUSD: *token.PriceUSD, LastUpdated: *token.LastUpdated}
+		if token.PriceChange24h != nil {
+			price.PriceChange24h = *token.PriceChange24h
+		}
+		return price, nil
+	}
+
+	coinGeckoID := external.TokenIDMappings[strings.ToLower(token.Symbol)]
+	if token.Metadata != nil && token.Metadata.CoinGeckoID != nil && *token.Metadata.CoinGeckoID != "" {
+		coinGeckoID = *token.Metadata.CoinGeckoID
+	}
+	if coinGeckoID == "" {
+		return nil, errors.BadRequest(fmt.Sprintf("No price source for %s", token.Symbol))
+	}
+
+	prices, err := s.priceClient.GetTokenPrices(ctx, []string{coinGeckoID})
+	if err != nil {
+		return nil, errors.ExternalServiceError("CoinGecko", err)
+	}
+	fetched, ok := prices[coinGeckoID]
+	if !ok {
+		return nil, errors.NotFound("Price")
+	}
+
+	savedAt, err := s.tokenRepo.SavePrice(ctx, tokenID, fetched.USD, fetched.USD24hChange)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	logger.Info("Refreshed token price on demand", "token", token.Symbol, "chainId", token.ChainID, "price", fetched.USD)
+	return &RefreshedPrice{
+		TokenID:        tokenID,
+		PriceUSD:       fetched.USD,
+		PriceChange24h: fetched.USD24hChange,
+		LastUpdated:    savedAt,
+		Refreshed:      true,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryTokenPriceRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*models.Token
+}
+
+func (r *memoryTokenPriceRepo) GetToken(ctx context.Context, tokenID uuid.UUID) (*models.Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenID]
+	if !ok {
+		return nil, repos.ErrTokenNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *memoryTokenPriceRepo) GetByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.TokenMetadata, error) {
+	return nil, nil
+}
+
+func (r *memoryTokenPriceRepo) GetStaleTokens(ctx context.Context, olderThan time.Duration, limit int) ([]*models.Token, error) {
+	return nil, nil
+}
+
+func (r *memoryTokenPriceRepo) Upsert(ctx context.Context, metadata *models.TokenMetadata) error {
+	return nil
+}
+
+func (r *memoryTokenPriceRepo) BoostPriceRefresh(ctx context.Context, tokenID uuid.UUID, until time.Time) error {
+	return nil
+}
+
+func (r *memoryTokenPriceRepo) SavePrice(ctx context.Context, tokenID uuid.UUID, priceUSD, priceChange24h float64) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	token := r.tokens[tokenID]
+	token.PriceUSD, token.PriceChange24h, token.LastUpdated = &priceUSD, &priceChange24h, &now
+	return now, nil
+}
+
+// slowPriceClient counts fetches and holds each one until released
+type slowPriceClient struct {
+	calls   atomic.Int32
+	release chan struct{}
+	prices  external.PriceResponse
+}
+
+func (c *slowPriceClient) GetTokenPrices(ctx context.Context, tokenIDs []string) (external.PriceResponse, error) {
+	c.calls.Add(1)
+	if c.release != nil {
+		<-c.release
+	}
+	prices := external.PriceResponse{}
+	for _, id := range tokenIDs {
+		if price, ok := c.prices[id]; ok {
+			prices[id] = price
+		}
+	}
+	return prices, nil
+}
+
+func newPriceRefreshFixture(token *models.Token) (*PriceRefreshService, *memoryTokenPriceRepo, *slowPriceClient) {
+	repo := &memoryTokenPriceRepo{tokens: map[uuid.UUID]*models.Token{token.ID: token}}
+	client := &slowPriceClient{prices: external.PriceResponse{
+		"ethereum": {USD: 3210.5, USD24hChange: -4.2},
+	}}
+	return NewPriceRefreshService(repo, client), repo, client
+}
+
+func TestRefreshPriceCoalescesConcurrentRequests(t *testing.T) {
+	stale := time.Now().Add(-10 * time.Minute)
+	oldPrice := 3000.0
+	token := &models.Token{ID: uuid.New(), Symbol: "ETH", ChainID: 1, PriceUSD: &oldPrice, LastUpdated: &stale}
+	service, repo, client := newPriceRefreshFixture(token)
+	client.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]*RefreshedPrice, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			price, err := service.RefreshPrice(context.Background(), token.ID)
+			require.NoError(t, err)
+			results[i] = price
+		}(i)
+	}
+	// Let every request join the in-flight fetch before it completes
+	require.Eventually(t, func() bool { return client.calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(client.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), client.calls.Load())
+	for _, price := range results {
+		assert.Equal(t, 3210.5, price.PriceUSD)
+		assert.Equal(t, -4.2, price.PriceChange24h)
+		assert.True(t, price.Refreshed)
+	}
+	assert.Equal(t, 3210.5, *repo.tokens[token.ID].PriceUSD)
+}
+
+func TestRefreshPriceReusesRecentPrice(t *testing.T) {
+	stale := time.Now().Add(-10 * time.Minute)
+	token := &models.Token{ID: uuid.New(), Symbol: "ETH", ChainID: 1, LastUpdated: &stale}
+	service, _, client := newPriceRefreshFixture(token)
+
+	first, err := service.RefreshPrice(context.Background(), token.ID)
+	require.NoError(t, err)
+	assert.True(t, first.Refreshed)
+
+	second, err := service.RefreshPrice(context.Background(), token.ID)
+	require.NoError(t, err)
+	assert.False(t, second.Refreshed, "a price fetched moments ago is returned as is")
+	assert.Equal(t, first.PriceUSD, second.PriceUSD)
+	assert.Equal(t, int32(1), client.calls.Load())
+}
+
+func TestRefreshPriceUsesEnrichedCoinGeckoID(t *testing.T) {
+	coinGeckoID := "ethereum"
+	token := &models.Token{ID: uuid.New(), Symbol: "EETH", ChainID: 1, Metadata: &models.TokenMetadata{CoinGeckoID: &coinGeckoID}}
+	service, _, _ := newPriceRefreshFixture(token)
+
+	price, err := service.RefreshPrice(context.Background(), token.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3210.5, price.PriceUSD)
+}
+
+func TestRefreshPriceErrors(t *testing.T) {
+	token := &models.Token{ID: uuid.New(), Symbol: "NOPE", ChainID: 1}
+	service, _, client := newPriceRefreshFixture(token)
+	ctx := context.Background()
+
+	_, err := service.RefreshPrice(ctx, token.ID)
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.Status)
+	assert.Zero(t, client.calls.Load())
+
+	_, err = service.RefreshPrice(ctx, uuid.New())
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 404, appErr.Status)
+}
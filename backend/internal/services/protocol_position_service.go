@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/adapters"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Protocol position actions
+const (
+	ProtocolActionClaim = "claim"
+	ProtocolActionExit  = "exit"
+)
+
+// ProtocolAdapterInfo describes a registered protocol adapter
+type ProtocolAdapterInfo struct {
+	Protocol string `json:"protocol"`
+	Chains   []int  `json:"chains"`
+}
+
+// ProtocolTxsRequest asks for the transactions claiming from or closing one detected position
+type ProtocolTxsRequest struct {
+	Action  string `json:"action"`
+	ChainID int    `json:"chain_id"`
+	Owner   string `json:"owner"`
+	// PositionID is the ID the position was listed with
+	PositionID string `json:"position_id"`
+}
+
+// ProtocolTxs are the unsigned transactions for a protocol position action
+type ProtocolTxs struct {
+	Protocol     string                  `json:"protocol"`
+	ChainID      int                     `json:"chain_id"`
+	Action       string                  `json:"action"`
+	PositionID   string                  `json:"position_id"`
+	Transactions []blockchain.PositionTx `json:"transactions"`
+}
+
+// ProtocolPositionService reads wallet positions through the protocol adapters registered
+// in pkg/adapters
+type ProtocolPositionService struct {
+	walletRepo repos.WalletRepository
+}
+
+func NewProtocolPositionService(walletRepo repos.WalletRepository) *ProtocolPositionService {
+	return &ProtocolPositionService{
+		walletRepo: walletRepo,
+	}
+}
+
+// protocolTarget is one wallet read with one adapter
+type protocolTarget struct {
+	wallet  *models.Wallet
+	adapter adapters.ProtocolAdapter
+}
+
+// GetAdapters lists the registered protocol adapters
+func (s *ProtocolPositionService) GetAdapters() []ProtocolAdapterInfo {
+	registered := adapters.All()
+	infos := make([]ProtocolAdapterInfo, len(registered))
+	for i, adapter := range registered {
+		infos[i] = ProtocolAdapterInfo{Protocol: adapter.Protocol(), Chains: adapter.Chains()}
+	}
+	return infos
+}
+
+// GetPositions returns the positions the registered adapters find in the user's wallets,
+// largest first, with values and pending rewards. address and protocol narrow the result.
+func (s *ProtocolPositionService) GetPositions(ctx context.Context, userID uuid.UUID, address, protocol, alchemyAPIKey string) ([]*adapters.Position, error) {
+	registered := adapters.All()
+	if protocol != "" {
+		adapter, ok := adapters.Get(protocol)
+		if !ok {
+			return nil, errors.NotFound("Protocol adapter")
+		}
+		registered = []adapters.ProtocolAdapter{adapter}
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+	if address != "" && !hasWalletAddress(wallets, address) {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	targets := protocolTargets(wallets, address, registered)
+	chain := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+	positions, failed := collectProtocolPositions(ctx, chain, targets)
+	if failed > 0 && failed == len(targets) {
+		return nil, errors.Internal("Failed to read protocol positions")
+	}
+
+	sortPositionsByValue(positions)
+	return positions, nil
+}
+
+// BuildTransactions returns the unsigned transactions claiming the rewards of, or closing,
+// one of the positions GetPositions lists. The position is detected again so the
+// transactions match its current state.
+func (s *ProtocolPositionService) BuildTransactions(ctx context.Context, userID uuid.UUID, protocol string, req ProtocolTxsRequest, alchemyAPIKey string) (*ProtocolTxs, error) {
+	adapter, ok := adapters.Get(protocol)
+	if !ok {
+		return nil, errors.NotFound("Protocol adapter")
+	}
+	if req.Action != ProtocolActionClaim && req.Action != ProtocolActionExit {
+		return nil, errors.BadRequest("Action must be claim or exit")
+	}
+	if req.PositionID == "" {
+		return nil, errors.BadRequest("Position ID is required")
+	}
+	if !adapters.SupportsChain(adapter, req.ChainID) {
+		return nil, errors.BadRequest("Protocol is not supported on this chain")
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+	if !hasWalletAddress(wallets, req.Owner) {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	chain := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+	positions, err := adapter.DetectPositions(ctx, chain, req.ChainID, req.Owner)
+	if err != nil {
+		logger.Error("Failed to detect protocol positions", "error", err, "protocol", protocol, "address", req.Owner, "chainID", req.ChainID)
+		return nil, errors.Internal("Failed to read protocol positions")
+	}
+	position := findPosition(positions, req.PositionID)
+	if position == nil {
+		return nil, errors.NotFound("Position")
+	}
+
+	var txs []blockchain.PositionTx
+	if req.Action == ProtocolActionClaim {
+		txs, err = adapter.BuildClaimTx(ctx, chain, position)
+	} else {
+		txs, err = adapter.BuildExitTx(ctx, chain, position)
+	}
+	if err != nil {
+		if stderrors.Is(err, adapters.ErrUnsupported) {
+			return nil, errors.BadRequest("Protocol does not support " + req.Action + " for this position")
+		}
+		logger.Error("Failed to build protocol transactions", "error", err, "protocol", protocol, "action", req.Action)
+		return nil, errors.BadRequest(err.Error())
+	}
+
+	return &ProtocolTxs{
+		Protocol:     protocol,
+		ChainID:      req.ChainID,
+		Action:       req.Action,
+		PositionID:   position.ID,
+		Transactions: txs,
+	}, nil
+}
+
+// collectProtocolPositions detects, values and reads the rewards of each target's
+// positions, returning how many targets couldn't be read. Valuation and reward errors
+// only leave the position without them.
+func collectProtocolPositions(ctx context.Context, chain adapters.Chain, targets []protocolTarget) ([]*adapters.Position, int) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		positions = []*adapters.Position{}
+		failed    int
+	)
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target protocolTarget) {
+			defer wg.Done()
+			protocol := target.adapter.Protocol()
+			detected, err := target.adapter.DetectPositions(ctx, chain, target.wallet.ChainID, target.wallet.Address)
+			if err != nil {
+				logger.Error("Failed to detect protocol positions", "error", err, "protocol", protocol, "address", target.wallet.Address, "chainID", target.wallet.ChainID)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return
+			}
+
+			for _, position := range detected {
+				if err := target.adapter.ValuePosition(ctx, chain, position); err != nil {
+					logger.Warn("Failed to value protocol position", "error", err, "protocol", protocol, "position", position.ID)
+				}
+				rewards, err := target.adapter.PendingRewards(ctx, chain, position)
+				if err != nil && !stderrors.Is(err, adapters.ErrUnsupported) {
+					logger.Warn("Failed to read pending rewards", "error", err, "protocol", protocol, "position", position.ID)
+				}
+				if len(rewards) > 0 {
+					// Prices each reward token so the rewards' composition shows in USD
+					if _, err := adapters.ValueTokens(ctx, chain, rewards); err != nil {
+						logger.Warn("Failed to value pending rewards", "error", err, "protocol", protocol, "position", position.ID)
+					}
+				}
+				position.Rewards = rewards
+			}
+
+			mu.Lock()
+			positions = append(positions, detected...)
+			mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+	return positions, failed
+}
+
+// protocolTargets pairs each wallet, or just the one at address, with the adapters that
+// can read its chain
+func protocolTargets(wallets []*models.Wallet, address string, registered []adapters.ProtocolAdapter) []protocolTarget {
+	var targets []protocolTarget
+	for _, wallet := range wallets {
+		if address != "" && !strings.EqualFold(wallet.Address, address) {
+			continue
+		}
+		for _, adapter := range registered {
+			if adapters.SupportsChain(adapter, wallet.ChainID) {
+				targets = append(targets, protocolTarget{wallet: wallet, adapter: adapter})
+			}
+		}
+	}
+	return targets
+}
+
+func hasWalletAddress(wallets []*models.Wallet, address string) bool {
+	for _, wallet := range wallets {
+		if strings.EqualFold(wallet.Address, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func findPosition(positions []*adapters.Position, id string) *adapters.Position {
+	for _, position := range positions {
+		if strings.EqualFold(position.ID, id) {
+			return position
+		}
+	}
+	return nil
+}
+
+// sortPositionsByValue orders positions by USD value, unpriced ones last
+func sortPositionsByValue(positions []*adapters.Position) {
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := positions[i].ValueUSD, positions[j].ValueUSD
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+}
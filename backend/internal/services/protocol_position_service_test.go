@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/adapters"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAdapter finds one position per wallet on chain 1, failing for the wallet in fail
+type stubAdapter struct {
+	fail       string
+	rewardsErr error
+}
+
+func (stubAdapter) Protocol() string { return "stub" }
+func (stubAdapter) Chains() []int    { return []int{1} }
+
+func (a stubAdapter) DetectPositions(_ context.Context, _ adapters.Chain, chainID int, owner string) ([]*adapters.Position, error) {
+	if owner == a.fail {
+		return nil, errors.New("rpc down")
+	}
+	return []*adapters.Position{{Protocol: "stub", ChainID: chainID, WalletAddress: owner, ID: owner + "-1"}}, nil
+}
+
+func (stubAdapter) ValuePosition(_ context.Context, _ adapters.Chain, position *adapters.Position) error {
+	value := float64(len(position.WalletAddress))
+	position.ValueUSD = &value
+	return nil
+}
+
+func (a stubAdapter) PendingRewards(context.Context, adapters.Chain, *adapters.Position) ([]adapters.TokenAmount, error) {
+	if a.rewardsErr != nil {
+		return nil, a.rewardsErr
+	}
+	return []adapters.TokenAmount{{Symbol: "RWD", Amount: 1}}, nil
+}
+
+func (stubAdapter) BuildClaimTx(context.Context, adapters.Chain, *adapters.Position) ([]blockchain.PositionTx, error) {
+	return nil, adapters.ErrUnsupported
+}
+
+func (stubAdapter) BuildExitTx(context.Context, adapters.Chain, *adapters.Position) ([]blockchain.PositionTx, error) {
+	return nil, nil
+}
+
+// stubChain only prices the stub reward token
+type stubChain struct{}
+
+func (stubChain) CallContract(context.Context, int, string, string, []byte) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubChain) Multicall(context.Context, int, []blockchain.Call) ([]blockchain.CallResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubChain) GetTokenMetadata(context.Context, int, []string) (map[string]blockchain.TokenMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubChain) GetSymbolPricesUSD(context.Context, []string) (map[string]float64, error) {
+	return map[string]float64{"RWD": 2}, nil
+}
+
+func TestProtocolTargets(t *testing.T) {
+	wallets := []*models.Wallet{
+		{Address: "0xAAA", ChainID: 1},
+		{Address: "0xBBB", ChainID: 1},
+		{Address: "0xCCC", ChainID: 137},
+	}
+	registered := []adapters.ProtocolAdapter{stubAdapter{}}
+
+	assert.Len(t, protocolTargets(wallets, "", registered), 2)
+	targets := protocolTargets(wallets, "0xaaa", registered)
+	require.Len(t, targets, 1)
+	assert.Equal(t, "0xAAA", targets[0].wallet.Address)
+	// No adapter reads chain 137
+	assert.Empty(t, protocolTargets(wallets, "0xCCC", registered))
+}
+
+func TestCollectProtocolPositions(t *testing.T) {
+	wallets := []*models.Wallet{{Address: "0xAAA", ChainID: 1}, {Address: "0xBBBB", ChainID: 1}, {Address: "0xC", ChainID: 1}}
+	adapter := stubAdapter{fail: "0xC"}
+
+	positions, failed := collectProtocolPositions(context.Background(), stubChain{}, protocolTargets(wallets, "", []adapters.ProtocolAdapter{adapter}))
+	assert.Equal(t, 1, failed)
+	require.Len(t, positions, 2)
+	sortPositionsByValue(positions)
+	assert.Equal(t, "0xBBBB-1", positions[0].ID)
+	require.Len(t, positions[0].Rewards, 1)
+	assert.Equal(t, 2.0, *positions[0].Rewards[0].ValueUSD)
+
+	// Reward errors leave the position without rewards
+	adapter.rewardsErr = errors.New("reverted")
+	positions, failed = collectProtocolPositions(context.Background(), stubChain{}, protocolTargets(wallets[:1], "", []adapters.ProtocolAdapter{adapter}))
+	assert.Zero(t, failed)
+	require.Len(t, positions, 1)
+	assert.Empty(t, positions[0].Rewards)
+}
+
+func TestFindPosition(t *testing.T) {
+	positions := []*adapters.Position{{ID: "0xAbC"}, {ID: "42"}}
+	assert.Equal(t, positions[0], findPosition(positions, "0xabc"))
+	assert.Equal(t, positions[1], findPosition(positions, "42"))
+	assert.Nil(t, findPosition(positions, "43"))
+}
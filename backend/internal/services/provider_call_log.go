@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/redact"
+)
+
+const (
+	// ProviderCallRetention is how long provider calls are kept
+	ProviderCallRetention = 14 * 24 * time.Hour
+	// providerCallBuffer is how many calls wait to be written before new ones are dropped
+	providerCallBuffer = 5000
+	// providerCallBatch is the most calls written in one statement
+	providerCallBatch = 500
+	// providerCallFlushInterval is how often waiting calls are written
+	providerCallFlushInterval = 2 * time.Second
+	// maxProviderCallEndpoint and maxProviderCallError bound the text kept per call
+	maxProviderCallEndpoint = 2048
+	maxProviderCallError    = 512
+	// maxProviderCallsListed caps how many calls one lookup returns
+	maxProviderCallsListed = 500
+)
+
+// providerHosts names the provider behind each host, matched on the host or a parent
+// domain. Calls to other hosts, such as webhooks and the worker, aren't logged.
+var providerHosts = map[string]string{
+	"alchemy.com":        "alchemy",
+	"polygon.technology": "polygon",
+	"polygonscan.com":    "polygonscan",
+	"coingecko.com":      "coingecko",
+	"llama.fi":           "defillama",
+	"li.quest":           "lifi",
+	"socket.tech":        "socket",
+	"0x.org":             "0x",
+	"1inch.io":           "1inch",
+	"1inch.dev":          "1inch",
+	"binance.com":        "binance",
+	"kraken.com":         "kraken",
+	"coinbase.com":       "coinbase",
+	"beaconcha.in":       "beaconchain",
+	"gmxinfra.io":        "gmx",
+	"hyperliquid.xyz":    "hyperliquid",
+	"blockstream.info":   "esplora",
+	"mempool.space":      "esplora",
+	"publicnode.com":     "cosmos",
+}
+
+// ProviderCallLog writes the calls made to upstream providers, as reported by the
+// correlation transport, so support can trace a request ID to the provider responses
+// behind it. Calls are written in batches in the background; when the database falls
+// behind, new calls are dropped rather than slowing the callers down.
+type ProviderCallLog struct {
+	repo   repos.ProviderCallRepository
+	source string
+	// all logs calls made outside API requests, such as by scheduled jobs, too
+	all     bool
+	calls   chan models.ProviderCall
+	dropped atomic.Int64
+}
+
+func NewProviderCallLog(repo repos.ProviderCallRepository, source string, all bool) *ProviderCallLog {
+	return &ProviderCallLog{
+		repo:   repo,
+		source: source,
+		all:    all,
+		calls:  make(chan models.ProviderCall, providerCallBuffer),
+	}
+}
+
+// Observe queues a call for writing; it's the observer given to correlation.Observe
+func (l *ProviderCallLog) Observe(call correlation.Call) {
+	c, ok := providerCall(call, l.source)
+	if !ok || (c.RequestID == nil && !l.all) {
+		return
+	}
+	select {
+	case l.calls <- *c:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+// Run writes queued calls until ctx is done, then writes what's left
+func (l *ProviderCallLog) Run(ctx context.Context) {
+	ticker := time.NewTicker(providerCallFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.ProviderCall, 0, providerCallBatch)
+	flush := func(ctx context.Context) {
+		if dropped := l.dropped.Swap(0); dropped > 0 {
+			logger.Warn("Dropped provider call logs", "count", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.repo.InsertBatch(ctx, batch); err != nil {
+			logger.Warn("Failed to write provider call logs", "count", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case c := <-l.calls:
+					batch = append(batch, c)
+					if len(batch) == providerCallBatch {
+						flush(context.Background())
+					}
+				default:
+					flush(context.Background())
+					return
+				}
+			}
+		case c := <-l.calls:
+			batch = append(batch, c)
+			if len(batch) == providerCallBatch {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// List returns the logged calls matching the filter, newest first
+func (l *ProviderCallLog) List(ctx context.Context, filter models.ProviderCallFilter) ([]models.ProviderCall, error) {
+	if filter.RequestID == "" && filter.Provider == "" && filter.From == nil {
+		return nil, errors.BadRequest("A request ID, provider or start time is required")
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return nil, errors.BadRequest("The end time must be after the start time")
+	}
+	if filter.Limit <= 0 || filter.Limit > maxProviderCallsListed {
+		filter.Limit = maxProviderCallsListed
+	}
+
+	calls, err := l.repo.List(ctx, filter)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return calls, nil
+}
+
+// providerCall turns a call into its log entry, reporting false for hosts that aren't
+// providers
+func providerCall(call correlation.Call, source string) (*models.ProviderCall, bool) {
+	provider := providerForHost(call.Host)
+	if provider == "" {
+		return nil, false
+	}
+
+	endpoint := redact.Path(call.Path)
+	if query := redact.Query(call.RawQuery); query != "" {
+		endpoint += "?" + query
+	}
+	c := &models.ProviderCall{
+		Provider:   provider,
+		Method:     call.Method,
+		Host:       call.Host,
+		Endpoint:   truncate(endpoint, maxProviderCallEndpoint),
+		DurationMs: int(call.Duration.Milliseconds()),
+		Source:     source,
+		StartedAt:  call.StartedAt,
+	}
+	if call.RequestID != "" {
+		id := truncate(call.RequestID, 128)
+		c.RequestID = &id
+	}
+	if call.Err != nil {
+		// URL errors repeat the URL, which may carry the API key
+		err := call.Err
+		var urlErr *url.Error
+		if stderrors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		msg := truncate(redact.Text(err.Error()), maxProviderCallError)
+		c.Error = &msg
+		return c, true
+	}
+	status, hash, size := call.Status, call.ResponseHash, call.ResponseBytes
+	c.Status, c.ResponseHash, c.ResponseBytes = &status, &hash, &size
+	return c, true
+}
+
+// providerForHost returns the provider serving the host, or "" if it isn't one
+func providerForHost(host string) string {
+	host = strings.ToLower(host)
+	for {
+		if provider, ok := providerHosts[host]; ok {
+			return provider
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			return ""
+		}
+		host = host[dot+1:]
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
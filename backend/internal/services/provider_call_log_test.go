@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderForHost(t *testing.T) {
+	assert.Equal(t, "alchemy", providerForHost("eth-mainnet.g.alchemy.com"))
+	assert.Equal(t, "coingecko", providerForHost("pro-api.coingecko.com"))
+	assert.Equal(t, "lifi", providerForHost("LI.QUEST"))
+	assert.Empty(t, providerForHost("hooks.slack.com"))
+	assert.Empty(t, providerForHost("worker"))
+}
+
+func TestProviderCall(t *testing.T) {
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	call := correlation.Call{
+		RequestID:     "req-1",
+		Method:        "POST",
+		Host:          "eth-mainnet.g.alchemy.com",
+		Path:          "/v2/aBcD1234eFgH5678iJkL9012mNoP",
+		Status:        200,
+		Duration:      150 * time.Millisecond,
+		ResponseHash:  "abc123",
+		ResponseBytes: 42,
+		StartedAt:     started,
+	}
+
+	c, ok := providerCall(call, models.ProviderCallSourceAPI)
+	require.True(t, ok)
+	assert.Equal(t, "alchemy", c.Provider)
+	assert.Equal(t, "/v2/[REDACTED]", c.Endpoint, "keys in the path aren't logged")
+	assert.Equal(t, "req-1", *c.RequestID)
+	assert.Equal(t, 200, *c.Status)
+	assert.Equal(t, "abc123", *c.ResponseHash)
+	assert.Equal(t, 150, c.DurationMs)
+	assert.Nil(t, c.Error)
+
+	// Query keys are dropped and failed calls keep the error without the URL
+	call = correlation.Call{
+		Method:    "GET",
+		Host:      "api.coingecko.com",
+		Path:      "/api/v3/simple/price",
+		RawQuery:  "ids=usd-coin&x_cg_pro_api_key=secret",
+		Err:       &url.Error{Op: "Get", URL: "https://api.coingecko.com/api/v3/simple/price?x_cg_pro_api_key=secret", Err: errors.New("connection refused")},
+		StartedAt: started,
+	}
+	c, ok = providerCall(call, models.ProviderCallSourceWorker)
+	require.True(t, ok)
+	assert.Equal(t, "/api/v3/simple/price?ids=usd-coin&x_cg_pro_api_key=[REDACTED]", c.Endpoint)
+	assert.Nil(t, c.RequestID)
+	assert.Nil(t, c.Status)
+	assert.Equal(t, "connection refused", *c.Error)
+
+	_, ok = providerCall(correlation.Call{Host: "hooks.slack.com"}, models.ProviderCallSourceAPI)
+	assert.False(t, ok)
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// maxPolicySlippage is the highest slippage cap a policy may set, in percent
+const maxPolicySlippage = 50
+
+// policyProviders are the providers policies can name
+var policyProviders = map[string]bool{
+	models.ProviderLiFi:    true,
+	models.ProviderSocket:  true,
+	models.ProviderZeroX:   true,
+	models.ProviderOneInch: true,
+	models.ProviderAll:     true,
+}
+
+// ProviderLimits is the policy in force for one provider on one chain
+type ProviderLimits struct {
+	Disabled bool
+	// Weight multiplies the provider's output amount when routes are ranked
+	Weight      float64
+	MaxSlippage *float64
+	MaxFeeUSD   *float64
+}
+
+// ProviderPolicySource resolves the policy in force for a provider on a chain
+type ProviderPolicySource interface {
+	Limits(provider string, chainID int) ProviderLimits
+}
+
+// ProviderPolicyService keeps the admin-set provider policies in memory for the quote
+// services. Changes made through it apply at once in this process; other processes
+// pick them up on their next reload.
+type ProviderPolicyService struct {
+	repo     repos.ProviderPolicyRepository
+	policies atomic.Pointer[[]models.ProviderPolicy]
+}
+
+func NewProviderPolicyService(repo repos.ProviderPolicyRepository) *ProviderPolicyService {
+	s := &ProviderPolicyService{repo: repo}
+	s.policies.Store(&[]models.ProviderPolicy{})
+	return s
+}
+
+// Load replaces the in-memory policies with the stored ones
+func (s *ProviderPolicyService) Load(ctx context.Context) error {
+	policies, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	s.policies.Store(&policies)
+	return nil
+}
+
+// Watch reloads the policies every interval until ctx is done. A failed reload keeps
+// the policies last loaded.
+func (s *ProviderPolicyService) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil {
+				logger.Warn("Failed to reload provider policies", "error", err)
+			}
+		}
+	}
+}
+
+func (s *ProviderPolicyService) Limits(provider string, chainID int) ProviderLimits {
+	return resolveProviderLimits(*s.policies.Load(), provider, chainID)
+}
+
+func (s *ProviderPolicyService) List(ctx context.Context) ([]models.ProviderPolicy, error) {
+	policies, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return policies, nil
+}
+
+// Upsert saves the policy for a provider and chain, replacing any already set
+func (s *ProviderPolicyService) Upsert(ctx context.Context, updatedBy uuid.UUID, req *models.UpsertProviderPolicyRequest) (*models.ProviderPolicy, error) {
+	if !policyProviders[req.Provider] {
+		return nil, errors.BadRequest("Unknown provider; expected lifi, socket, 0x, 1inch or *")
+	}
+	if req.ChainID != nil && *req.ChainID <= 0 {
+		return nil, errors.BadRequest("Chain ID must be positive")
+	}
+	if req.Weight != nil && *req.Weight <= 0 {
+		return nil, errors.BadRequest("Weight must be greater than 0")
+	}
+	if req.MaxSlippage != nil && (*req.MaxSlippage <= 0 || *req.MaxSlippage > maxPolicySlippage) {
+		return nil, errors.BadRequest("Max slippage must be greater than 0 and at most 50 percent")
+	}
+	if req.MaxFeeUSD != nil && *req.MaxFeeUSD < 0 {
+		return nil, errors.BadRequest("Max fee must not be negative")
+	}
+
+	policy := &models.ProviderPolicy{
+		Provider:    req.Provider,
+		ChainID:     req.ChainID,
+		Disabled:    req.Disabled,
+		Weight:      req.Weight,
+		MaxSlippage: req.MaxSlippage,
+		MaxFeeUSD:   req.MaxFeeUSD,
+		Reason:      req.Reason,
+		UpdatedBy:   &updatedBy,
+	}
+	if err := s.repo.Upsert(ctx, policy); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	logger.Info("Provider policy saved", "provider", policy.Provider, "chainId", policy.ChainID,
+		"disabled", policy.Disabled, "updatedBy", updatedBy)
+
+	s.reload(ctx)
+	return policy, nil
+}
+
+func (s *ProviderPolicyService) Delete(ctx context.Context, id uuid.UUID) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Provider policy")
+	}
+
+	s.reload(ctx)
+	return nil
+}
+
+// reload applies a change in this process straight away; the stored change stands and
+// the next scheduled reload catches up if this one fails
+func (s *ProviderPolicyService) reload(ctx context.Context) {
+	if err := s.Load(ctx); err != nil {
+		logger.Warn("Failed to reload provider policies", "error", err)
+	}
+}
+
+// resolveProviderLimits merges the policies that apply to a provider on a chain. Broader
+// policies apply first so narrower ones override their limits, but any disabling policy
+// wins, which makes a disabled '*' policy a kill switch.
+func resolveProviderLimits(policies []models.ProviderPolicy, provider string, chainID int) ProviderLimits {
+	var applicable []models.ProviderPolicy
+	for _, p := range policies {
+		if (p.Provider == provider || p.Provider == models.ProviderAll) && (p.ChainID == nil || *p.ChainID == chainID) {
+			applicable = append(applicable, p)
+		}
+	}
+	sort.SliceStable(applicable, func(i, j int) bool {
+		return policySpecificity(applicable[i]) < policySpecificity(applicable[j])
+	})
+
+	limits := ProviderLimits{Weight: 1}
+	for _, p := range applicable {
+		limits.Disabled = limits.Disabled || p.Disabled
+		if p.Weight != nil {
+			limits.Weight = *p.Weight
+		}
+		if p.MaxSlippage != nil {
+			limits.MaxSlippage = p.MaxSlippage
+		}
+		if p.MaxFeeUSD != nil {
+			limits.MaxFeeUSD = p.MaxFeeUSD
+		}
+	}
+	return limits
+}
+
+// policySpecificity ranks a named provider above '*' and a single chain above every chain
+func policySpecificity(p models.ProviderPolicy) int {
+	rank := 0
+	if p.Provider != models.ProviderAll {
+		rank += 2
+	}
+	if p.ChainID != nil {
+		rank++
+	}
+	return rank
+}
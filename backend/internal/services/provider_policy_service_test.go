@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveProviderLimits(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	chain := func(id int) *int { return &id }
+
+	// No policies leave a provider enabled at full weight
+	limits := resolveProviderLimits(nil, models.ProviderLiFi, 1)
+	assert.Equal(t, ProviderLimits{Weight: 1}, limits)
+
+	policies := []models.ProviderPolicy{
+		{Provider: models.ProviderLiFi, ChainID: chain(137), MaxSlippage: f(1)},
+		{Provider: models.ProviderAll, MaxSlippage: f(3), MaxFeeUSD: f(50)},
+		{Provider: models.ProviderLiFi, Weight: f(0.8), MaxSlippage: f(2)},
+		{Provider: models.ProviderSocket, ChainID: chain(10), Disabled: true},
+	}
+
+	// Narrower policies override broader ones whatever order they're stored in
+	limits = resolveProviderLimits(policies, models.ProviderLiFi, 137)
+	assert.False(t, limits.Disabled)
+	assert.Equal(t, 0.8, limits.Weight)
+	assert.Equal(t, 1.0, *limits.MaxSlippage)
+	assert.Equal(t, 50.0, *limits.MaxFeeUSD)
+
+	limits = resolveProviderLimits(policies, models.ProviderLiFi, 1)
+	assert.Equal(t, 2.0, *limits.MaxSlippage)
+
+	// Disabling applies to its provider and chain only
+	assert.True(t, resolveProviderLimits(policies, models.ProviderSocket, 10).Disabled)
+	assert.False(t, resolveProviderLimits(policies, models.ProviderSocket, 1).Disabled)
+	assert.False(t, resolveProviderLimits(policies, models.ProviderLiFi, 10).Disabled)
+
+	// A disabled '*' policy is a kill switch that narrower policies can't re-enable
+	killed := append(policies, models.ProviderPolicy{Provider: models.ProviderAll, Disabled: true})
+	for _, provider := range []string{models.ProviderLiFi, models.ProviderSocket, models.ProviderZeroX, models.ProviderOneInch} {
+		assert.True(t, resolveProviderLimits(killed, provider, 137).Disabled, provider)
+	}
+}
+
+func TestQuotePolicyHelpers(t *testing.T) {
+	capped := 1.0
+	maxFee := 5.0
+	limits := ProviderLimits{Weight: 1, MaxSlippage: &capped, MaxFeeUSD: &maxFee}
+
+	assert.Equal(t, 1.0, cappedSlippage(3, limits))
+	assert.Equal(t, 0.5, cappedSlippage(0.5, limits))
+	assert.Equal(t, 3.0, cappedSlippage(3, defaultProviderLimits))
+
+	assert.True(t, withinFeeCap("4.99", limits))
+	assert.False(t, withinFeeCap("5.01", limits))
+	assert.True(t, withinFeeCap("1000", defaultProviderLimits))
+
+	// Weights can reorder routes; ties keep provider order
+	ranked := []rankedRoute[string]{
+		{route: "lifi", score: routeScore("1000000", 0.9)},
+		{route: "socket", score: routeScore("990000", 1)},
+		{route: "other", score: routeScore("990000", 1)},
+	}
+	assert.Equal(t, []string{"socket", "other", "lifi"}, sortRanked(ranked))
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// exportQuotaWindow is the period exports are counted over
+const exportQuotaWindow = 24 * time.Hour
+
+// quotaResources are the plan-limited resources in the order they're reported
+var quotaResources = []string{
+	models.QuotaWallets,
+	models.QuotaAlerts,
+	models.QuotaWatchedAddresses,
+	models.QuotaExportsPerDay,
+	models.QuotaWebhookChannels,
+}
+
+// planLimits are each tier's limits
+var planLimits = map[string]map[string]int{
+	models.PlanTierFree: {
+		models.QuotaWallets:          3,
+		models.QuotaAlerts:           10,
+		models.QuotaWatchedAddresses: 5,
+		models.QuotaExportsPerDay:    5,
+		models.QuotaWebhookChannels:  1,
+	},
+	models.PlanTierPro: {
+		models.QuotaWallets:          50,
+		models.QuotaAlerts:           250,
+		models.QuotaWatchedAddresses: 100,
+		models.QuotaExportsPerDay:    100,
+		models.QuotaWebhookChannels:  10,
+	},
+}
+
+// QuotaChecker tells whether a user may add one more of a plan-limited resource
+type QuotaChecker interface {
+	CheckQuota(ctx context.Context, userID uuid.UUID, resource string) error
+}
+
+// QuotaExceededDetails are the details of the error for a user who reached the limit on
+// a resource, saying which limit it was so clients can offer an upgrade
+type QuotaExceededDetails struct {
+	Resource string `json:"resource"`
+	Limit    int    `json:"limit"`
+	Used     int    `json:"used"`
+	Tier     string `json:"tier"`
+}
+
+// QuotaService enforces the limits of users' plan tiers. Quotas are soft: they only
+// stop users adding more once at the limit, so whatever a user has beyond it, say
+// after a downgrade or a lowered override, keeps working.
+type QuotaService struct {
+	repo  repos.PlanRepository
+	clock clock.Clock
+}
+
+func NewQuotaService(repo repos.PlanRepository) *QuotaService {
+	return &QuotaService{repo: repo, clock: clock.System}
+}
+
+// GetQuota returns the user's plan tier with their usage of each limited resource
+func (s *QuotaService) GetQuota(ctx context.Context, userID uuid.UUID) (*models.UserQuota, error) {
+	plan, err := s.repo.GetPlan(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	usage, err := s.repo.GetUsage(ctx, userID, s.clock.Now().Add(-exportQuotaWindow))
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return userQuota(plan, usage, s.clock.Now()), nil
+}
+
+// CheckQuota returns a QUOTA_EXCEEDED error if the user is at their limit on resource
+func (s *QuotaService) CheckQuota(ctx context.Context, userID uuid.UUID, resource string) error {
+	quota, err := s.GetQuota(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, usage := range quota.Quotas {
+		if usage.Resource == resource && usage.Exceeded {
+			return quotaExceeded(quota.Tier, usage)
+		}
+	}
+	return nil
+}
+
+// SetPlan changes the user's plan on an admin's behalf, returning their quota under it
+func (s *QuotaService) SetPlan(ctx context.Context, adminID, userID uuid.UUID, req *models.SetUserPlanRequest) (*models.UserQuota, error) {
+	plan, err := s.repo.GetPlan(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+
+	if req.Tier != nil {
+		tier := strings.ToLower(strings.TrimSpace(*req.Tier))
+		if _, ok := planLimits[tier]; !ok {
+			return nil, errors.BadRequest("Invalid tier. Must be one of: free, pro")
+		}
+		plan.Tier = tier
+	}
+	if req.LimitOverrides != nil {
+		for resource, limit := range req.LimitOverrides {
+			if _, ok := planLimits[models.PlanTierFree][resource]; !ok {
+				return nil, errors.BadRequest(fmt.Sprintf("Unknown quota %q. Must be one of: %s", resource, strings.Join(quotaResources, ", ")))
+			}
+			if limit < 0 {
+				return nil, errors.BadRequest(fmt.Sprintf("Limit for %s can't be negative", resource))
+			}
+		}
+		plan.LimitOverrides = req.LimitOverrides
+	}
+	if req.Note != nil {
+		plan.Note = req.Note
+		if strings.TrimSpace(*req.Note) == "" {
+			plan.Note = nil
+		}
+	}
+	plan.UpdatedBy = &adminID
+
+	found, err := s.repo.SetPlan(ctx, plan)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if !found {
+		return nil, errors.NotFound("User")
+	}
+	return s.GetQuota(ctx, userID)
+}
+
+// AlertService wraps alerts so creating one counts against the alert quota and, for an
+// alert on an address not yet watched, the watched address quota
+func (s *QuotaService) AlertService(alerts AlertService) AlertService {
+	return &quotaAlertService{AlertService: alerts, quota: s}
+}
+
+type quotaAlertService struct {
+	AlertService
+	quota *QuotaService
+}
+
+func (s *quotaAlertService) CreateAlert(ctx context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.Alert, error) {
+	if err := s.quota.CheckQuota(ctx, userID, models.QuotaAlerts); err != nil {
+		return nil, err
+	}
+	if req.Target.Type == "address" && req.Target.Identifier != "" {
+		watched, err := s.quota.repo.WatchesAddress(ctx, userID, req.Target.Identifier)
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		if !watched {
+			if err := s.quota.CheckQuota(ctx, userID, models.QuotaWatchedAddresses); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.AlertService.CreateAlert(ctx, userID, req)
+}
+
+// userQuota lays the user's usage out against the limits of their plan's tier at now
+func userQuota(plan *models.UserPlan, usage map[string]int, now time.Time) *models.UserQuota {
+	tier := plan.EffectiveTier(now)
+	limits, ok := planLimits[tier]
+	if !ok {
+		limits = planLimits[models.PlanTierFree]
+	}
+
+	quota := &models.UserQuota{Tier: tier, Quotas: make([]models.QuotaUsage, 0, len(quotaResources))}
+	if tier != plan.Tier {
+		quota.TrialEndsAt = plan.TrialEndsAt
+	}
+	for _, resource := range quotaResources {
+		entry := models.QuotaUsage{Resource: resource, Limit: limits[resource], Used: usage[resource]}
+		if limit, ok := plan.LimitOverrides[resource]; ok {
+			entry.Limit, entry.Overridden = limit, true
+		}
+		entry.Exceeded = entry.Used >= entry.Limit
+		quota.Quotas = append(quota.Quotas, entry)
+	}
+	return quota
+}
+
+func quotaExceeded(tier string, usage models.QuotaUsage) *errors.AppError {
+	message := fmt.Sprintf("Your plan allows at most %d %s", usage.Limit, strings.ReplaceAll(usage.Resource, "_", " "))
+	if usage.Resource == models.QuotaExportsPerDay {
+		message = fmt.Sprintf("Your plan allows at most %d exports a day", usage.Limit)
+	}
+	return &errors.AppError{
+		Code:    "QUOTA_EXCEEDED",
+		Message: message,
+		Details: QuotaExceededDetails{Resource: usage.Resource, Limit: usage.Limit, Used: usage.Used, Tier: tier},
+		Status:  http.StatusForbidden,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPlans keeps one user's plan in memory; alerts added through planAlerts count
+// toward its usage
+type memoryPlans struct {
+	plan    *models.UserPlan
+	usage   map[string]int
+	watched []string
+}
+
+func (m *memoryPlans) GetPlan(_ context.Context, userID uuid.UUID) (*models.UserPlan, error) {
+	if m.plan == nil {
+		return &models.UserPlan{UserID: userID, Tier: models.PlanTierFree, LimitOverrides: map[string]int{}}, nil
+	}
+	copied := *m.plan
+	return &copied, nil
+}
+
+func (m *memoryPlans) SetPlan(_ context.Context, plan *models.UserPlan) (bool, error) {
+	now := time.Now()
+	plan.UpdatedAt = &now
+	m.plan = plan
+	return true, nil
+}
+
+func (m *memoryPlans) ExtendTrial(_ context.Context, userID uuid.UUID, by time.Duration) (*time.Time, error) {
+	if m.plan == nil {
+		m.plan = &models.UserPlan{UserID: userID, Tier: models.PlanTierFree, LimitOverrides: map[string]int{}}
+	}
+	trialEndsAt := time.Now()
+	if m.plan.TrialEndsAt != nil && m.plan.TrialEndsAt.After(trialEndsAt) {
+		trialEndsAt = *m.plan.TrialEndsAt
+	}
+	trialEndsAt = trialEndsAt.Add(by)
+	m.plan.TrialEndsAt = &trialEndsAt
+	return &trialEndsAt, nil
+}
+
+func (m *memoryPlans) GetUsage(context.Context, uuid.UUID, time.Time) (map[string]int, error) {
+	return m.usage, nil
+}
+
+func (m *memoryPlans) WatchesAddress(_ context.Context, _ uuid.UUID, address string) (bool, error) {
+	for _, watched := range m.watched {
+		if strings.EqualFold(watched, address) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// planAlerts creates alerts, counting them in the plan's usage
+type planAlerts struct {
+	AlertService
+	plans *memoryPlans
+}
+
+func (a *planAlerts) CreateAlert(_ context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.Alert, error) {
+	a.plans.usage[models.QuotaAlerts]++
+	if req.Target.Type == "address" {
+		if watched, _ := a.plans.WatchesAddress(context.Background(), userID, req.Target.Identifier); !watched {
+			a.plans.watched = append(a.plans.watched, req.Target.Identifier)
+			a.plans.usage[models.QuotaWatchedAddresses]++
+		}
+	}
+	return &models.Alert{ID: uuid.New(), UserID: userID, Type: req.Type, Target: req.Target}, nil
+}
+
+func TestQuotaAlerts(t *testing.T) {
+	ctx := context.Background()
+	userID, adminID := uuid.New(), uuid.New()
+	plans := &memoryPlans{usage: map[string]int{models.QuotaAlerts: 2}}
+	quota := NewQuotaService(plans)
+	alerts := quota.AlertService(&planAlerts{plans: plans})
+	watch := func(address string) error {
+		_, err := alerts.CreateAlert(ctx, userID, &models.CreateAlertRequest{
+			Type:   models.AlertTypeLargeTransfer,
+			Target: models.AlertTarget{Type: "address", Identifier: address, ChainID: 1},
+		})
+		return err
+	}
+
+	// The free plan watches five addresses; alerts on one already watched don't count
+	for i := 0; i < 5; i++ {
+		require.NoError(t, watch("0x000000000000000000000000000000000000000"+string(rune('1'+i))))
+	}
+	require.NoError(t, watch("0x0000000000000000000000000000000000000001"))
+	err := watch("0x0000000000000000000000000000000000000009")
+	require.Error(t, err)
+	appErr := err.(*errors.AppError)
+	assert.Equal(t, "QUOTA_EXCEEDED", appErr.Code)
+	assert.Equal(t, 403, appErr.Status)
+	assert.Equal(t, QuotaExceededDetails{Resource: models.QuotaWatchedAddresses, Limit: 5, Used: 5, Tier: models.PlanTierFree}, appErr.Details)
+
+	// Eight alerts so far; two more reach the free plan's ten
+	require.NoError(t, watch("0x0000000000000000000000000000000000000002"))
+	require.NoError(t, watch("0x0000000000000000000000000000000000000003"))
+	err = watch("0x0000000000000000000000000000000000000004")
+	require.Error(t, err)
+	assert.Equal(t, models.QuotaAlerts, err.(*errors.AppError).Details.(QuotaExceededDetails).Resource)
+
+	// Upgrading lifts the limits; an override takes precedence over the tier's
+	pro := models.PlanTierPro
+	usage, err := quota.SetPlan(ctx, adminID, userID, &models.SetUserPlanRequest{Tier: &pro, LimitOverrides: map[string]int{models.QuotaWatchedAddresses: 6}})
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanTierPro, usage.Tier)
+	assert.Equal(t, models.QuotaUsage{Resource: models.QuotaAlerts, Limit: 250, Used: 10}, usage.Quotas[1])
+	assert.Equal(t, models.QuotaUsage{Resource: models.QuotaWatchedAddresses, Limit: 6, Used: 5, Overridden: true}, usage.Quotas[2])
+	assert.Equal(t, adminID, *plans.plan.UpdatedBy)
+	require.NoError(t, watch("0x0000000000000000000000000000000000000009"))
+	assert.Error(t, watch("0x000000000000000000000000000000000000000a"))
+
+	// Downgrading keeps what's there but allows nothing more
+	free := models.PlanTierFree
+	usage, err = quota.SetPlan(ctx, adminID, userID, &models.SetUserPlanRequest{Tier: &free, LimitOverrides: map[string]int{}})
+	require.NoError(t, err)
+	assert.Equal(t, models.QuotaUsage{Resource: models.QuotaAlerts, Limit: 10, Used: 11, Exceeded: true}, usage.Quotas[1])
+	assert.Error(t, quota.CheckQuota(ctx, userID, models.QuotaAlerts))
+	assert.NoError(t, quota.CheckQuota(ctx, userID, models.QuotaWallets))
+
+	for _, bad := range []*models.SetUserPlanRequest{
+		{Tier: new(string)},
+		{LimitOverrides: map[string]int{"pools": 3}},
+		{LimitOverrides: map[string]int{models.QuotaWallets: -1}},
+	} {
+		_, err := quota.SetPlan(ctx, adminID, userID, bad)
+		require.Error(t, err)
+		assert.Equal(t, 400, err.(*errors.AppError).Status)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// sharedQuoteTimeout bounds a quote request shared by concurrent callers, which outlives
+// the request that started it
+const sharedQuoteTimeout = 30 * time.Second
+
+// shareQuotes runs fetch for a user's quote request unless the same user's identical
+// request, by key, is being quoted already, in which case it waits for that answer. A
+// double-clicked "get quote" then asks the providers once. Each caller gets its own
+// copy of the routes.
+func shareQuotes[T any](ctx context.Context, group *singleflight.Group, userID uuid.UUID, key string, fetch func(ctx context.Context) ([]T, error)) ([]T, error) {
+	ch := group.DoChan(userID.String()+"|"+key, func() (interface{}, error) {
+		// The fetch is shared, so the caller that started it going away mustn't cancel it
+		// for the rest
+		fetchCtx, cancel := context.WithTimeout(correlation.Detach(ctx), sharedQuoteTimeout)
+		defer cancel()
+		return fetch(fetchCtx)
+	})
+
+	select {
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return append([]T(nil), result.Val.([]T)...), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// swapQuoteKey identifies a swap quote request, so requests differing only in address
+// case or how the amount is written are the same
+func swapQuoteKey(req SwapQuoteRequest) string {
+	return fmt.Sprintf("swap|%d|%s|%s|%s|%s|%s|%s",
+		req.ChainID, normalizeQuoteAddress(req.FromToken), normalizeQuoteAddress(req.ToToken),
+		normalizeQuoteAmount(req.FromAmount), normalizeQuoteAddress(req.UserAddress),
+		strconv.FormatFloat(req.Slippage, 'f', -1, 64), normalizeQuoteAmount(req.GasPrice))
+}
+
+// bridgeRouteKey identifies a bridge route request like swapQuoteKey
+func bridgeRouteKey(req BridgeRouteRequest) string {
+	return fmt.Sprintf("bridge|%d|%d|%s|%s|%s|%s|%s|%s|%t",
+		req.FromChain, req.ToChain, normalizeQuoteAddress(req.FromToken), normalizeQuoteAddress(req.ToToken),
+		normalizeQuoteAmount(req.FromAmount), normalizeQuoteAddress(req.UserAddress),
+		strconv.FormatFloat(req.Slippage, 'f', -1, 64), strconv.FormatFloat(req.MaxSlippage, 'f', -1, 64),
+		req.WantsDestinationGas)
+}
+
+func normalizeQuoteAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// normalizeQuoteAmount writes a base-unit amount without leading zeros; amounts that
+// aren't integers are kept as given, and providers reject them
+func normalizeQuoteAmount(amount string) string {
+	amount = strings.TrimSpace(amount)
+	if n, ok := new(big.Int).SetString(amount, 10); ok {
+		return n.String()
+	}
+	return amount
+}
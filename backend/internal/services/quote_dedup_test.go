@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/singleflight"
+)
+
+func TestShareQuotes_ConcurrentIdenticalRequests(t *testing.T) {
+	var group singleflight.Group
+	alice, bob := uuid.New(), uuid.New()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]SwapRoute, error) {
+		calls.Add(1)
+		<-release
+		return []SwapRoute{{ID: "0x-route"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]SwapRoute, 3)
+	for i, userID := range []uuid.UUID{alice, alice, bob} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			routes, err := shareQuotes(context.Background(), &group, userID, "swap|1", fetch)
+			assert.NoError(t, err)
+			results[i] = routes
+		}()
+	}
+	// Alice's double click shares one fetch; Bob's request is his own
+	require.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), calls.Load())
+	for _, routes := range results {
+		assert.Equal(t, []SwapRoute{{ID: "0x-route"}}, routes)
+	}
+	// Callers get their own copies
+	results[0][0].ID = "changed"
+	assert.Equal(t, "0x-route", results[1][0].ID)
+}
+
+func TestShareQuotes_CallerGivingUp(t *testing.T) {
+	var group singleflight.Group
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fetchErr := make(chan error, 1)
+	fetch := func(ctx context.Context) ([]SwapRoute, error) {
+		close(started)
+		<-release
+		fetchErr <- ctx.Err()
+		return []SwapRoute{{ID: "route"}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := shareQuotes(ctx, &group, uuid.New(), "swap|1", fetch)
+		first <- err
+	}()
+	<-started
+
+	// The caller that started the fetch going away doesn't cancel it for the others
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(release)
+	assert.NoError(t, <-fetchErr)
+}
+
+func TestSwapQuoteKey_Normalizes(t *testing.T) {
+	req := SwapQuoteRequest{
+		ChainID:     1,
+		FromToken:   "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		ToToken:     "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		FromAmount:  "1000000000000000000",
+		UserAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		Slippage:    0.5,
+	}
+	same := req
+	same.FromToken = " 0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	same.UserAddress = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	same.FromAmount = "0001000000000000000000"
+	assert.Equal(t, swapQuoteKey(req), swapQuoteKey(same))
+
+	other := req
+	other.Slippage = 1
+	assert.NotEqual(t, swapQuoteKey(req), swapQuoteKey(other))
+}
+
+func TestBridgeRouteKey_Normalizes(t *testing.T) {
+	req := BridgeRouteRequest{
+		FromChain:   1,
+		ToChain:     137,
+		FromToken:   "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		ToToken:     "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359",
+		FromAmount:  "1000000",
+		UserAddress: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		Slippage:    0.5,
+	}
+	same := req
+	same.ToToken = "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359"
+	assert.Equal(t, bridgeRouteKey(req), bridgeRouteKey(same))
+
+	other := req
+	other.MaxSlippage = 2
+	assert.NotEqual(t, bridgeRouteKey(req), bridgeRouteKey(other))
+}
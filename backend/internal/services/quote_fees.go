@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// Fee types of a route's cost breakdown, whatever the provider calls its fees
+const (
+	QuoteFeeGas        = "gas"
+	QuoteFeeProtocol   = "protocol"
+	QuoteFeeBridge     = "bridge"
+	QuoteFeeIntegrator = "integrator"
+)
+
+// nativeQuoteAddress is the address aggregators use for a chain's native asset
+const nativeQuoteAddress = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+// QuoteCost is what a route costs in fees, in USD and in the output token. Providers
+// report fees in token amounts, in USD or as a percentage, and some leave gas out; the
+// cost prices them all the same way, so routes from different providers compare honestly.
+type QuoteCost struct {
+	// TotalUSD sums the fees that could be priced; Complete is false when one couldn't
+	TotalUSD float64 `json:"totalUsd"`
+	Complete bool    `json:"complete"`
+	// TotalToToken is TotalUSD in the output token's base units, like toAmount; it's
+	// empty when the output token has no price
+	TotalToToken string     `json:"totalToToken,omitempty"`
+	Breakdown    []QuoteFee `json:"breakdown"`
+}
+
+// QuoteFee is one fee of a route
+type QuoteFee struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Token       string `json:"token"`
+	Symbol      string `json:"symbol,omitempty"`
+	// Amount is in the token's base units
+	Amount    string   `json:"amount"`
+	AmountUSD *float64 `json:"amountUsd"`
+}
+
+// QuoteTokenPrices prices the tokens fees are charged in; repos.TokenRepository is one
+type QuoteTokenPrices interface {
+	GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error)
+}
+
+// quoteFeeType maps a provider's name for a fee to one of the breakdown's types
+func quoteFeeType(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "gas"):
+		return QuoteFeeGas
+	case strings.Contains(name, "integrator"):
+		return QuoteFeeIntegrator
+	case strings.Contains(name, "bridge"), strings.Contains(name, "relay"), strings.Contains(name, "lp fee"):
+		return QuoteFeeBridge
+	default:
+		return QuoteFeeProtocol
+	}
+}
+
+// pricedFee is a fee on its way into the breakdown
+type pricedFee struct {
+	fee      QuoteFee
+	chainID  int
+	raw      *big.Int
+	decimals int
+}
+
+// quoteCost prices a quote's fees. Fees the provider didn't price in USD are priced from
+// prices, which may be nil; gas is estimated from the quote's gas limit and price, or
+// gasPrice when set, unless the provider listed it.
+func quoteCost(ctx context.Context, prices QuoteTokenPrices, quote clients.Quote, gasPrice string) *QuoteCost {
+	fromChain, _ := strconv.Atoi(quote.FromChainID)
+	toChain, err := strconv.Atoi(quote.ToChainID)
+	if err != nil {
+		toChain = fromChain
+	}
+
+	var fees []*pricedFee
+	hasGas := false
+	for _, f := range quote.Fees {
+		fee := &pricedFee{
+			fee:      QuoteFee{Type: quoteFeeType(f.Type), Description: f.Description, Token: f.Token.Address, Symbol: f.Token.Symbol},
+			chainID:  fromChain,
+			decimals: f.Token.Decimals,
+		}
+		if chainID, err := strconv.Atoi(f.Token.ChainID); err == nil && chainID > 0 {
+			fee.chainID = chainID
+		}
+		// Fees without a token, like 0x's protocol fee, are paid in the native asset
+		if fee.fee.Token == "" {
+			fee.fee.Token = blockchain.NativeTokenAddress
+			fee.decimals = 18
+		}
+		if raw, err := amounts.ParseBaseUnits(f.Amount); err == nil {
+			fee.raw = raw
+		} else if pct, err := decimal.Parse(f.Percentage); err == nil && f.Percentage != "" {
+			// A fee given only as a fraction of the input is charged in the input token
+			if from, err := amounts.ParseBaseUnits(quote.FromAmount); err == nil {
+				share := decimal.NewFromBigInt(from, 0).Mul(pct).Round(0)
+				fee.raw, _ = new(big.Int).SetString(share.StringFixed(0), 10)
+				fee.fee.Token, fee.fee.Symbol, fee.decimals = quote.FromToken.Address, quote.FromToken.Symbol, quote.FromToken.Decimals
+			}
+		}
+		if usd, err := strconv.ParseFloat(f.AmountUSD, 64); err == nil && f.AmountUSD != "" {
+			fee.fee.AmountUSD = &usd
+		}
+		if fee.raw == nil && fee.fee.AmountUSD == nil {
+			continue
+		}
+		hasGas = hasGas || fee.fee.Type == QuoteFeeGas
+		fees = append(fees, fee)
+	}
+
+	if !hasGas {
+		if gas := estimatedGasFee(quote, gasPrice, fromChain); gas != nil {
+			fees = append(fees, gas)
+		}
+	}
+
+	tokens := quoteFeeTokens(ctx, prices, fees, toChain, quote.ToToken.Address)
+
+	cost := &QuoteCost{Complete: true, Breakdown: make([]QuoteFee, 0, len(fees))}
+	for _, fee := range fees {
+		if fee.raw != nil {
+			fee.fee.Amount = fee.raw.String()
+		}
+		if fee.fee.AmountUSD == nil && fee.raw != nil {
+			if token := tokens[quoteTokenKey(fee.chainID, fee.fee.Token)]; token != nil && token.PriceUSD != nil {
+				decimals := fee.decimals
+				if decimals == 0 {
+					decimals = token.Decimals
+				}
+				if usd, err := amounts.USDValue(fee.raw, decimals, *token.PriceUSD); err == nil {
+					f := usd.Float64()
+					fee.fee.AmountUSD = &f
+				}
+				if fee.fee.Symbol == "" {
+					fee.fee.Symbol = token.Symbol
+				}
+			}
+		}
+		if fee.fee.AmountUSD == nil {
+			cost.Complete = false
+		} else {
+			cost.TotalUSD += *fee.fee.AmountUSD
+		}
+		cost.Breakdown = append(cost.Breakdown, fee.fee)
+	}
+
+	if out := tokens[quoteTokenKey(toChain, quote.ToToken.Address)]; out != nil && out.PriceUSD != nil && *out.PriceUSD > 0 {
+		decimals := quote.ToToken.Decimals
+		if decimals == 0 {
+			decimals = out.Decimals
+		}
+		units := decimal.NewFromFloat(cost.TotalUSD).DivRound(decimal.NewFromFloat(*out.PriceUSD), int32(decimals))
+		if raw, err := amounts.FromUnits(units, decimals); err == nil {
+			cost.TotalToToken = raw.String()
+		}
+	}
+	return cost
+}
+
+// feeCapTotal is the USD fee total provider fee caps apply to: the route's cost when
+// every fee could be priced, else the fees the provider priced itself
+func feeCapTotal(providerTotal string, cost *QuoteCost) string {
+	if cost == nil || !cost.Complete {
+		return providerTotal
+	}
+	return strconv.FormatFloat(cost.TotalUSD, 'f', 6, 64)
+}
+
+// estimatedGasFee is the native asset a quote's transaction burns in gas, when the
+// quote says how much gas at what price
+func estimatedGasFee(quote clients.Quote, gasPrice string, chainID int) *pricedFee {
+	if gasPrice == "" {
+		gasPrice = quote.GasPriceWei
+	}
+	limit, err := amounts.ParseBaseUnits(quote.EstimatedGas)
+	if err != nil || limit.Sign() == 0 {
+		return nil
+	}
+	price, err := amounts.ParseBaseUnits(gasPrice)
+	if err != nil || price.Sign() == 0 {
+		return nil
+	}
+	native := blockchain.NativeToken(chainID)
+	return &pricedFee{
+		fee:      QuoteFee{Type: QuoteFeeGas, Description: "Estimated network gas", Token: native.Address, Symbol: native.Symbol},
+		chainID:  chainID,
+		raw:      new(big.Int).Mul(limit, price),
+		decimals: native.Decimals,
+	}
+}
+
+// quoteFeeTokens looks up the tokens of unpriced fees and the output token, by
+// quoteTokenKey. Lookups that fail leave those fees unpriced.
+func quoteFeeTokens(ctx context.Context, prices QuoteTokenPrices, fees []*pricedFee, toChain int, toToken string) map[string]*models.Token {
+	tokens := make(map[string]*models.Token)
+	if prices == nil {
+		return tokens
+	}
+
+	byChain := map[int][]string{toChain: {quoteTokenAddress(toToken)}}
+	for _, fee := range fees {
+		if fee.fee.AmountUSD == nil && fee.raw != nil {
+			byChain[fee.chainID] = append(byChain[fee.chainID], quoteTokenAddress(fee.fee.Token))
+		}
+	}
+	for chainID, addresses := range byChain {
+		found, err := prices.GetTokensByAddresses(ctx, chainID, addresses)
+		if err != nil {
+			logger.Warn("Failed to price quote fees", "chainId", chainID, "error", err)
+			continue
+		}
+		for address, token := range found {
+			tokens[quoteTokenKey(chainID, address)] = token
+		}
+	}
+	return tokens
+}
+
+// quoteTokenAddress is the address a quote's token is stored under: lowercase, with
+// the native asset at the zero address
+func quoteTokenAddress(address string) string {
+	address = strings.ToLower(address)
+	if address == nativeQuoteAddress || address == "" {
+		return blockchain.NativeTokenAddress
+	}
+	return address
+}
+
+func quoteTokenKey(chainID int, address string) string {
+	return strconv.Itoa(chainID) + ":" + quoteTokenAddress(address)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuotePrices prices tokens by chain and lowercase address
+type fakeQuotePrices map[string]float64
+
+func (f fakeQuotePrices) GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error) {
+	found := make(map[string]*models.Token)
+	for _, address := range addresses {
+		if price, ok := f[quoteTokenKey(chainID, address)]; ok {
+			found[strings.ToLower(address)] = &models.Token{Address: address, ChainID: chainID, Decimals: 18, PriceUSD: &price}
+		}
+	}
+	return found, nil
+}
+
+const (
+	feeTestWETH = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	feeTestUSDC = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+)
+
+func feeTestQuote() clients.Quote {
+	return clients.Quote{
+		FromChainID: "1",
+		FromToken:   clients.Token{Address: feeTestWETH, Symbol: "WETH", Decimals: 18, ChainID: "1"},
+		ToToken:     clients.Token{Address: feeTestUSDC, Symbol: "USDC", Decimals: 6, ChainID: "1"},
+		FromAmount:  "1000000000000000000",
+		ToAmount:    "3000000000",
+	}
+}
+
+func TestQuoteCost_ProviderPricedFees(t *testing.T) {
+	quote := feeTestQuote()
+	quote.Fees = []clients.Fee{
+		{Type: "LIFI Fixed Fee", Amount: "2500000000000000", AmountUSD: "7.50", Token: clients.Token{Address: feeTestWETH, Symbol: "WETH", Decimals: 18, ChainID: "1"}},
+		{Type: "gas", Amount: "1000000000000000", AmountUSD: "3.00", Token: clients.Token{Address: nativeQuoteAddress, Symbol: "ETH", Decimals: 18, ChainID: "1"}},
+	}
+
+	cost := quoteCost(context.Background(), fakeQuotePrices{"1:" + strings.ToLower(feeTestUSDC): 1}, quote, "")
+
+	assert.True(t, cost.Complete)
+	assert.InDelta(t, 10.5, cost.TotalUSD, 1e-9)
+	assert.Equal(t, "10500000", cost.TotalToToken)
+	require.Len(t, cost.Breakdown, 2)
+	assert.Equal(t, QuoteFeeProtocol, cost.Breakdown[0].Type)
+	// The provider listed gas, so none is estimated
+	assert.Equal(t, QuoteFeeGas, cost.Breakdown[1].Type)
+	assert.Equal(t, "1000000000000000", cost.Breakdown[1].Amount)
+}
+
+func TestQuoteCost_PricesNativeFeesAndEstimatesGas(t *testing.T) {
+	// 0x-style: a protocol fee in wei with no token or USD amount, and no gas fee
+	quote := feeTestQuote()
+	quote.EstimatedGas = "0x30d40" // 200000
+	quote.GasPriceWei = "20000000000"
+	quote.Fees = []clients.Fee{{Type: "protocol", Amount: "1000000000000000"}}
+	prices := fakeQuotePrices{"1:0x0000000000000000000000000000000000000000": 3000, "1:" + strings.ToLower(feeTestUSDC): 1}
+
+	cost := quoteCost(context.Background(), prices, quote, "")
+
+	assert.True(t, cost.Complete)
+	require.Len(t, cost.Breakdown, 2)
+	assert.Equal(t, QuoteFeeProtocol, cost.Breakdown[0].Type)
+	require.NotNil(t, cost.Breakdown[0].AmountUSD)
+	assert.InDelta(t, 3.0, *cost.Breakdown[0].AmountUSD, 1e-9)
+	// 200000 gas at 20 gwei
+	assert.Equal(t, QuoteFeeGas, cost.Breakdown[1].Type)
+	assert.Equal(t, "4000000000000000", cost.Breakdown[1].Amount)
+	assert.Equal(t, "ETH", cost.Breakdown[1].Symbol)
+	require.NotNil(t, cost.Breakdown[1].AmountUSD)
+	assert.InDelta(t, 12.0, *cost.Breakdown[1].AmountUSD, 1e-9)
+	assert.InDelta(t, 15.0, cost.TotalUSD, 1e-9)
+	assert.Equal(t, "15000000", cost.TotalToToken)
+
+	// The caller's gas price wins over the provider's
+	cost = quoteCost(context.Background(), prices, quote, "10000000000")
+	assert.Equal(t, "2000000000000000", cost.Breakdown[1].Amount)
+}
+
+func TestQuoteCost_PercentageFee(t *testing.T) {
+	quote := feeTestQuote()
+	quote.Fees = []clients.Fee{{Type: "integrator", Percentage: "0.003"}}
+	prices := fakeQuotePrices{"1:" + strings.ToLower(feeTestWETH): 3000}
+
+	cost := quoteCost(context.Background(), prices, quote, "")
+
+	require.Len(t, cost.Breakdown, 1)
+	fee := cost.Breakdown[0]
+	assert.Equal(t, QuoteFeeIntegrator, fee.Type)
+	assert.Equal(t, feeTestWETH, fee.Token)
+	assert.Equal(t, "3000000000000000", fee.Amount)
+	require.NotNil(t, fee.AmountUSD)
+	assert.InDelta(t, 9.0, *fee.AmountUSD, 1e-9)
+	// USDC has no price, so the cost can't be given in it
+	assert.Empty(t, cost.TotalToToken)
+}
+
+func TestQuoteCost_Unpriced(t *testing.T) {
+	quote := feeTestQuote()
+	quote.Fees = []clients.Fee{
+		{Type: "bridge", Amount: "500000", Token: clients.Token{Address: feeTestUSDC, Decimals: 6, ChainID: "1"}},
+		{Type: "gas", Amount: "1000", AmountUSD: "2.5"},
+	}
+
+	cost := quoteCost(context.Background(), nil, quote, "")
+
+	assert.False(t, cost.Complete)
+	assert.InDelta(t, 2.5, cost.TotalUSD, 1e-9)
+	assert.Equal(t, QuoteFeeBridge, cost.Breakdown[0].Type)
+	assert.Nil(t, cost.Breakdown[0].AmountUSD)
+
+	// Fee caps then fall back to the provider's total
+	assert.Equal(t, "1.25", feeCapTotal("1.25", cost))
+	cost.Complete = true
+	assert.Equal(t, "2.500000", feeCapTotal("1.25", cost))
+}
+
+func TestQuoteFeeType(t *testing.T) {
+	assert.Equal(t, QuoteFeeGas, quoteFeeType("Gas"))
+	assert.Equal(t, QuoteFeeIntegrator, quoteFeeType("Integrator Fee"))
+	assert.Equal(t, QuoteFeeBridge, quoteFeeType("relay fee"))
+	assert.Equal(t, QuoteFeeBridge, quoteFeeType("LP Fee"))
+	assert.Equal(t, QuoteFeeProtocol, quoteFeeType("LIFI Fixed Fee"))
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/errors"
+)
+
+// defaultProviderLimits apply when no policy source is set
+var defaultProviderLimits = ProviderLimits{Weight: 1}
+
+// rankedRoute is a route with the score it's ranked by
+type rankedRoute[T any] struct {
+	route T
+	score float64
+}
+
+// sortRanked returns routes best score first, keeping provider order on ties
+func sortRanked[T any](ranked []rankedRoute[T]) []T {
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	routes := make([]T, len(ranked))
+	for i, r := range ranked {
+		routes[i] = r.route
+	}
+	return routes
+}
+
+// routeScore weights a route's output amount, in base units, by its provider's weight
+func routeScore(toAmount string, weight float64) float64 {
+	amount, err := amounts.ParseBaseUnits(toAmount)
+	if err != nil {
+		return 0
+	}
+	// Routes are ranked against others for the same token, so its decimals don't matter
+	return amounts.Float64(amount, 0) * weight
+}
+
+// cappedSlippage lowers the requested slippage to the provider's cap
+func cappedSlippage(slippage float64, limits ProviderLimits) float64 {
+	if limits.MaxSlippage != nil && slippage > *limits.MaxSlippage {
+		return *limits.MaxSlippage
+	}
+	return slippage
+}
+
+// withinFeeCap reports whether a route's total fees in USD are within the provider's cap.
+// Fees that can't be read pass, as they do when ranking.
+func withinFeeCap(totalFeeUSD string, limits ProviderLimits) bool {
+	if limits.MaxFeeUSD == nil {
+		return true
+	}
+	fee, err := strconv.ParseFloat(totalFeeUSD, 64)
+	return err != nil || fee <= *limits.MaxFeeUSD
+}
+
+// errQuotingDisabled is returned when policies disable every provider for a request
+func errQuotingDisabled(kind string) *errors.AppError {
+	return errors.New("QUOTING_DISABLED", kind+" quotes are temporarily disabled", 503)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// referralCodeLength is the length of generated referral codes
+	referralCodeLength = 8
+	// referralCodeAlphabet leaves out characters easily mistaken for one another
+	referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// referralSignupWindow is how long after signing up a user can still enter a code
+	referralSignupWindow = 7 * 24 * time.Hour
+	// referrerRewardDays and referredRewardDays are the pro trial days each side of a
+	// referral gets
+	referrerRewardDays = 30
+	referredRewardDays = 14
+	// maxReferralRewards is how many referrals earn a referrer days; referrals past it
+	// still reward the user referred
+	maxReferralRewards = 12
+	// recentReferrals is how many referrals the stats list
+	recentReferrals = 20
+)
+
+// ReferralService gives each user a referral code and rewards both sides of a referral
+// with a pro trial. Referrals between users who share a wallet or email are taken for
+// self-referrals and recorded as rejected, without a reward.
+type ReferralService struct {
+	referralRepo repos.ReferralRepository
+	planRepo     repos.PlanRepository
+	userRepo     repos.UserRepository
+	clock        clock.Clock
+}
+
+func NewReferralService(referralRepo repos.ReferralRepository, planRepo repos.PlanRepository, userRepo repos.UserRepository) *ReferralService {
+	return &ReferralService{
+		referralRepo: referralRepo,
+		planRepo:     planRepo,
+		userRepo:     userRepo,
+		clock:        clock.System,
+	}
+}
+
+// GetStats returns the user's referral code, creating it on first use, with how their
+// referrals have done
+func (s *ReferralService) GetStats(ctx context.Context, userID uuid.UUID) (*models.ReferralStats, error) {
+	code, err := s.code(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.referralRepo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	stats.Recent, err = s.referralRepo.ListByReferrer(ctx, userID, recentReferrals)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	stats.Code = code
+	stats.RewardsLeft = maxReferralRewards - stats.Rewarded
+	if stats.RewardsLeft < 0 {
+		stats.RewardsLeft = 0
+	}
+	return stats, nil
+}
+
+// Claim attributes the user's signup to the owner of a referral code. It must be used
+// within referralSignupWindow of signing up, once. Self-referrals are recorded and
+// returned as rejected.
+func (s *ReferralService) Claim(ctx context.Context, userID uuid.UUID, code string) (*models.Referral, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, errors.BadRequest("Referral code is required")
+	}
+	referrerID, ok, err := s.referralRepo.GetReferrer(ctx, code)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if !ok {
+		return nil, errors.NotFound("Referral code")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NotFound("User")
+	}
+	if s.clock.Now().Sub(user.CreatedAt) > referralSignupWindow {
+		return nil, errors.BadRequest("Referral codes can only be used in the first 7 days after signing up")
+	}
+	existing, err := s.referralRepo.GetByReferred(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if existing != nil {
+		return nil, errors.Conflict("A referral code was already used")
+	}
+
+	referral := &models.Referral{ReferrerID: referrerID, ReferredID: userID, Code: code, Status: models.ReferralRewarded}
+	reason, err := s.selfReferral(ctx, referrerID, user)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		referral.Status, referral.RejectReason = models.ReferralRejected, &reason
+		logger.Warn("Rejected self-referral", "referrerId", referrerID, "referredId", userID, "reason", reason)
+	} else {
+		stats, err := s.referralRepo.GetStats(ctx, referrerID)
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		referral.ReferredRewardDays = referredRewardDays
+		if stats.Rewarded < maxReferralRewards {
+			referral.ReferrerRewardDays = referrerRewardDays
+		}
+	}
+
+	created, err := s.referralRepo.Create(ctx, referral)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if !created {
+		return nil, errors.Conflict("A referral code was already used")
+	}
+
+	// The referral is recorded first so a retried claim can't grant the trial twice
+	for id, days := range map[uuid.UUID]int{userID: referral.ReferredRewardDays, referrerID: referral.ReferrerRewardDays} {
+		if days == 0 {
+			continue
+		}
+		if _, err := s.planRepo.ExtendTrial(ctx, id, time.Duration(days)*24*time.Hour); err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+	}
+	return referral, nil
+}
+
+// selfReferral returns why referring the user looks like the referrer referring
+// themselves, "" if it doesn't
+func (s *ReferralService) selfReferral(ctx context.Context, referrerID uuid.UUID, user *models.User) (string, error) {
+	if referrerID == user.ID {
+		return models.ReferralRejectSelf, nil
+	}
+	shared, err := s.referralRepo.SharesWallet(ctx, referrerID, user.ID)
+	if err != nil {
+		return "", errors.DatabaseError(err)
+	}
+	if shared {
+		return models.ReferralRejectSameWallet, nil
+	}
+	if user.Email != nil {
+		referrer, err := s.userRepo.GetByID(ctx, referrerID)
+		if err == nil && referrer.Email != nil && strings.EqualFold(*referrer.Email, *user.Email) {
+			return models.ReferralRejectSameEmail, nil
+		}
+	}
+	return "", nil
+}
+
+// code returns the user's referral code, generating one if they have none
+func (s *ReferralService) code(ctx context.Context, userID uuid.UUID) (string, error) {
+	code, err := s.referralRepo.GetCode(ctx, userID)
+	if err != nil {
+		return "", errors.DatabaseError(err)
+	}
+	// Retry the rare code already taken by someone else
+	for attempt := 0; code == "" && attempt < 5; attempt++ {
+		candidate, err := newReferralCode()
+		if err != nil {
+			return "", errors.Internal("Failed to generate referral code")
+		}
+		if code, err = s.referralRepo.CreateCode(ctx, userID, candidate); err != nil {
+			return "", errors.DatabaseError(err)
+		}
+	}
+	if code == "" {
+		return "", errors.Internal("Failed to generate referral code")
+	}
+	return code, nil
+}
+
+func newReferralCode() (string, error) {
+	b := make([]byte, referralCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = referralCodeAlphabet[int(b[i])%len(referralCodeAlphabet)]
+	}
+	return string(b), nil
+}
\ No newline at end of file
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryReferralRepo struct {
+	codes     map[uuid.UUID]string
+	referrals []*models.Referral
+	// shared pairs users who share a wallet
+	shared map[[2]uuid.UUID]bool
+}
+
+func (r *memoryReferralRepo) GetCode(_ context.Context, userID uuid.UUID) (string, error) {
+	return r.codes[userID], nil
+}
+
+func (r *memoryReferralRepo) CreateCode(_ context.Context, userID uuid.UUID, code string) (string, error) {
+	if _, ok := r.codes[userID]; !ok {
+		r.codes[userID] = code
+	}
+	return r.codes[userID], nil
+}
+
+func (r *memoryReferralRepo) GetReferrer(_ context.Context, code string) (uuid.UUID, bool, error) {
+	for userID, userCode := range r.codes {
+		if userCode == code {
+			return userID, true, nil
+		}
+	}
+	return uuid.Nil, false, nil
+}
+
+func (r *memoryReferralRepo) GetByReferred(_ context.Context, referredID uuid.UUID) (*models.Referral, error) {
+	for _, referral := range r.referrals {
+		if referral.ReferredID == referredID {
+			return referral, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryReferralRepo) Create(ctx context.Context, referral *models.Referral) (bool, error) {
+	if existing, _ := r.GetByReferred(ctx, referral.ReferredID); existing != nil {
+		return false, nil
+	}
+	referral.ID, referral.CreatedAt = uuid.New(), time.Now()
+	r.referrals = append(r.referrals, referral)
+	return true, nil
+}
+
+func (r *memoryReferralRepo) GetStats(_ context.Context, referrerID uuid.UUID) (*models.ReferralStats, error) {
+	stats := &models.ReferralStats{}
+	for _, referral := range r.referrals {
+		if referral.ReferrerID != referrerID {
+			continue
+		}
+		stats.Referred++
+		if referral.Status == models.ReferralRejected {
+			stats.Rejected++
+		} else if referral.ReferrerRewardDays > 0 {
+			stats.Rewarded++
+		}
+		stats.RewardDays += referral.ReferrerRewardDays
+	}
+	return stats, nil
+}
+
+func (r *memoryReferralRepo) ListByReferrer(_ context.Context, referrerID uuid.UUID, limit int) ([]models.Referral, error) {
+	referrals := []models.Referral{}
+	for _, referral := range r.referrals {
+		if referral.ReferrerID == referrerID {
+			referrals = append(referrals, *referral)
+		}
+	}
+	return referrals, nil
+}
+
+func (r *memoryReferralRepo) SharesWallet(_ context.Context, userID, otherID uuid.UUID) (bool, error) {
+	return r.shared[[2]uuid.UUID{userID, otherID}], nil
+}
+
+// memoryUsers holds users by ID
+type memoryUsers struct {
+	repos.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (u *memoryUsers) add(createdAt time.Time, email string) *models.User {
+	user := &models.User{ID: uuid.New(), CreatedAt: createdAt}
+	if email != "" {
+		user.Email = &email
+	}
+	u.users[user.ID] = user
+	return user
+}
+
+func (u *memoryUsers) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	if user, ok := u.users[id]; ok {
+		return user, nil
+	}
+	return nil, errors.NotFound("User")
+}
+
+// trialPlans keeps each user's plan, for trials granted to several users
+type trialPlans struct {
+	memoryPlans
+	trials map[uuid.UUID]time.Duration
+}
+
+func (p *trialPlans) ExtendTrial(_ context.Context, userID uuid.UUID, by time.Duration) (*time.Time, error) {
+	p.trials[userID] += by
+	trialEndsAt := time.Now().Add(p.trials[userID])
+	return &trialEndsAt, nil
+}
+
+func TestReferralServiceClaim(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	users := &memoryUsers{users: map[uuid.UUID]*models.User{}}
+	referralRepo := &memoryReferralRepo{codes: map[uuid.UUID]string{}, shared: map[[2]uuid.UUID]bool{}}
+	plans := &trialPlans{trials: map[uuid.UUID]time.Duration{}}
+	service := NewReferralService(referralRepo, plans, users)
+	service.clock = clock.NewFake(now)
+
+	referrer := users.add(now.Add(-90*24*time.Hour), "alice@example.org")
+	stats, err := service.GetStats(ctx, referrer.ID)
+	require.NoError(t, err)
+	require.Len(t, stats.Code, referralCodeLength)
+	code := stats.Code
+	again, err := service.GetStats(ctx, referrer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, code, again.Code, "the code is kept once created")
+
+	// A new user's signup rewards both sides with a pro trial
+	newcomer := users.add(now.Add(-time.Hour), "")
+	referral, err := service.Claim(ctx, newcomer.ID, " "+strings.ToLower(code)+" ")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReferralRewarded, referral.Status)
+	assert.Equal(t, referredRewardDays*24*time.Hour, plans.trials[newcomer.ID])
+	assert.Equal(t, referrerRewardDays*24*time.Hour, plans.trials[referrer.ID])
+
+	_, err = service.Claim(ctx, newcomer.ID, code)
+	require.Error(t, err, "a user is referred once")
+	assert.Equal(t, http.StatusConflict, err.(*errors.AppError).Status)
+
+	veteran := users.add(now.Add(-8*24*time.Hour), "")
+	_, err = service.Claim(ctx, veteran.ID, code)
+	require.Error(t, err, "codes are only taken shortly after signing up")
+	assert.Equal(t, http.StatusBadRequest, err.(*errors.AppError).Status)
+
+	_, err = service.Claim(ctx, newcomer.ID, "NOSUCHCD")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, err.(*errors.AppError).Status)
+
+	// Self-referrals are recorded but not rewarded
+	sameWallet := users.add(now, "")
+	referralRepo.shared[[2]uuid.UUID{referrer.ID, sameWallet.ID}] = true
+	sameEmail := users.add(now, "Alice@Example.org")
+	for user, reason := range map[*models.User]string{
+		referrer:   models.ReferralRejectSelf,
+		sameWallet: models.ReferralRejectSameWallet,
+		sameEmail:  models.ReferralRejectSameEmail,
+	} {
+		if user == referrer {
+			// The referrer signed up long ago; pretend otherwise to reach the check
+			user.CreatedAt = now
+		}
+		referral, err := service.Claim(ctx, user.ID, code)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReferralRejected, referral.Status)
+		assert.Equal(t, reason, *referral.RejectReason)
+		assert.Zero(t, referral.ReferredRewardDays)
+		assert.Zero(t, referral.ReferrerRewardDays)
+		if user != referrer {
+			assert.Zero(t, plans.trials[user.ID])
+		}
+	}
+	assert.Equal(t, referrerRewardDays*24*time.Hour, plans.trials[referrer.ID])
+
+	// Past the cap, referrals still reward the user referred
+	for i := 1; i < maxReferralRewards; i++ {
+		_, err := service.Claim(ctx, users.add(now, "").ID, code)
+		require.NoError(t, err)
+	}
+	late := users.add(now, "")
+	referral, err = service.Claim(ctx, late.ID, code)
+	require.NoError(t, err)
+	assert.Zero(t, referral.ReferrerRewardDays)
+	assert.Equal(t, referredRewardDays, referral.ReferredRewardDays)
+
+	stats, err = service.GetStats(ctx, referrer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, maxReferralRewards+4, stats.Referred)
+	assert.Equal(t, maxReferralRewards, stats.Rewarded)
+	assert.Equal(t, 3, stats.Rejected)
+	assert.Equal(t, maxReferralRewards*referrerRewardDays, stats.RewardDays)
+	assert.Zero(t, stats.RewardsLeft)
+}
+
+func TestQuotaTrial(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+	trialEndsAt := now.Add(time.Hour)
+	plan := &models.UserPlan{UserID: userID, Tier: models.PlanTierFree, TrialEndsAt: &trialEndsAt}
+
+	quota := userQuota(plan, map[string]int{}, now)
+	assert.Equal(t, models.PlanTierPro, quota.Tier)
+	assert.Equal(t, &trialEndsAt, quota.TrialEndsAt)
+	assert.Equal(t, 250, quota.Quotas[1].Limit)
+
+	quota = userQuota(plan, map[string]int{}, now.Add(2*time.Hour))
+	assert.Equal(t, models.PlanTierFree, quota.Tier)
+	assert.Nil(t, quota.TrialEndsAt)
+}
\ No newline at end of file
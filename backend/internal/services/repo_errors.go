@@ -0,0 +1,22 @@
+package services
+
+import (
+	stderrors "errors"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+)
+
+// repoError maps an error from looking up or writing resource to what the API returns:
+// a missing record is a 404, a write clashing with an existing one a 409, and anything
+// else, like a dropped connection, a database failure
+func repoError(err error, resource string) error {
+	switch {
+	case stderrors.Is(err, repos.ErrNotFound):
+		return errors.NotFound(resource)
+	case stderrors.Is(err, repos.ErrConflict):
+		return errors.Conflict(resource + " already exists")
+	default:
+		return errors.DatabaseError(err)
+	}
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/defi-dashboard/backend/pkg/report"
+	"github.com/google/uuid"
+)
+
+const statementMonthLayout = "2006-01"
+
+// statementLinkExpiry is how long emailed statement links stay valid
+const statementLinkExpiry = 7 * 24 * time.Hour
+
+// StatementMailer emails users a link to a newly generated statement
+type StatementMailer interface {
+	SendStatementEmail(ctx context.Context, to string, statement *models.ReportStatement, downloadURL string) error
+}
+
+func (LogEmailSender) SendStatementEmail(ctx context.Context, to string, statement *models.ReportStatement, downloadURL string) error {
+	logger.Info("Statement email", "to", to, "statementID", statement.ID, "period", statement.PeriodStart.Format(statementMonthLayout), "url", downloadURL)
+	return nil
+}
+
+// ReportService generates monthly PDF portfolio statements covering holdings across
+// wallets, Bitcoin accounts and exchanges, realized PnL and gas spend
+type ReportService struct {
+	reportRepo       repos.ReportRepository
+	walletRepo       repos.WalletRepository
+	userRepo         repos.UserRepository
+	transactionRepo  repos.TransactionRepository
+	portfolioService *PortfolioService
+	bitcoinService   *BitcoinService
+	exchangeService  *ExchangeService
+	pnlService       pnl.Service
+	uploadService    *UploadService
+	mailer           StatementMailer
+	clock            clock.Clock
+}
+
+func NewReportService(
+	reportRepo repos.ReportRepository,
+	walletRepo repos.WalletRepository,
+	userRepo repos.UserRepository,
+	transactionRepo repos.TransactionRepository,
+	portfolioService *PortfolioService,
+	bitcoinService *BitcoinService,
+	exchangeService *ExchangeService,
+	pnlService pnl.Service,
+	uploadService *UploadService,
+	mailer StatementMailer,
+) *ReportService {
+	if mailer == nil {
+		mailer = LogEmailSender{}
+	}
+	return &ReportService{
+		reportRepo:       reportRepo,
+		walletRepo:       walletRepo,
+		userRepo:         userRepo,
+		transactionRepo:  transactionRepo,
+		portfolioService: portfolioService,
+		bitcoinService:   bitcoinService,
+		exchangeService:  exchangeService,
+		pnlService:       pnlService,
+		uploadService:    uploadService,
+		mailer:           mailer,
+		clock:            clock.System,
+	}
+}
+
+// GetPreferences returns the user's statement preferences
+func (s *ReportService) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.ReportPreferences, error) {
+	prefs, err := s.reportRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get report preferences", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch report preferences")
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences applies a partial update to the user's statement preferences
+func (s *ReportService) UpdatePreferences(ctx context.Context, userID uuid.UUID, req *models.UpdateReportPreferencesRequest) (*models.ReportPreferences, error) {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.MonthlyEnabled != nil {
+		prefs.MonthlyEnabled = *req.MonthlyEnabled
+	}
+	if req.EmailEnabled != nil {
+		prefs.EmailEnabled = *req.EmailEnabled
+	}
+
+	if err := s.reportRepo.UpsertPreferences(ctx, prefs); err != nil {
+		logger.Error("Failed to update report preferences", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to update report preferences")
+	}
+	return prefs, nil
+}
+
+// GetStatements returns a page of the user's statements, newest first
+func (s *ReportService) GetStatements(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ReportStatement, error) {
+	statements, err := s.reportRepo.GetStatements(ctx, userID, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get report statements", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch statements")
+	}
+	return statements, nil
+}
+
+// GetStatementFile returns one of the user's statements with its PDF
+func (s *ReportService) GetStatementFile(ctx context.Context, userID, statementID uuid.UUID) (*models.ReportStatement, []byte, error) {
+	statement, err := s.reportRepo.GetStatement(ctx, userID, statementID)
+	if err != nil {
+		logger.Error("Failed to get report statement", "error", err, "statementID", statementID)
+		return nil, nil, errors.Internal("Failed to fetch statement")
+	}
+	if statement == nil {
+		return nil, nil, errors.NotFound("Statement")
+	}
+
+	upload, err := s.uploadService.Get(ctx, userID, statement.UploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.uploadService.Read(ctx, upload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return statement, data, nil
+}
+
+// GenerateStatement renders and stores the user's statement for a completed month given
+// as YYYY-MM, replacing any earlier statement for that month
+func (s *ReportService) GenerateStatement(ctx context.Context, userID uuid.UUID, month string) (*models.ReportStatement, error) {
+	periodStart, err := time.Parse(statementMonthLayout, month)
+	if err != nil {
+		return nil, errors.BadRequest("Month must be formatted as YYYY-MM")
+	}
+	if !periodStart.Before(statementPeriodStart(s.clock.Now())) {
+		return nil, errors.BadRequest("Statements can only be generated for completed months")
+	}
+
+	statement, err := s.generate(ctx, userID, periodStart)
+	if err != nil {
+		logger.Error("Failed to generate statement", "error", err, "userID", userID, "month", month)
+		return nil, errors.Internal("Failed to generate statement")
+	}
+	return statement, nil
+}
+
+// GenerateMonthly generates last month's statement for every opted-in user who doesn't
+// have it yet and emails them a link, returning how many were generated. Failures are
+// logged and retried on the next run.
+func (s *ReportService) GenerateMonthly(ctx context.Context) (int, error) {
+	periodStart := statementPeriodStart(s.clock.Now()).AddDate(0, -1, 0)
+	userIDs, err := s.reportRepo.GetUsersDueStatement(ctx, periodStart)
+	if err != nil {
+		return 0, err
+	}
+
+	generated := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return generated, ctx.Err()
+		}
+
+		statement, err := s.generate(ctx, userID, periodStart)
+		if err != nil {
+			logger.Error("Failed to generate monthly statement", "error", err, "userID", userID)
+			continue
+		}
+		generated++
+
+		if err := s.emailStatement(ctx, statement); err != nil {
+			logger.Error("Failed to email statement", "error", err, "userID", userID, "statementID", statement.ID)
+		}
+	}
+
+	return generated, nil
+}
+
+func (s *ReportService) generate(ctx context.Context, userID uuid.UUID, periodStart time.Time) (*models.ReportStatement, error) {
+	data, err := s.buildStatement(ctx, userID, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	pdf := report.RenderStatement(data)
+
+	filename := fmt.Sprintf("statement_%s.pdf", periodStart.Format(statementMonthLayout))
+	upload, err := s.uploadService.Save(ctx, userID, models.UploadKindReport, filename, "application/pdf", pdf)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.ReportStatement{
+		UserID:        userID,
+		PeriodStart:   data.PeriodStart,
+		PeriodEnd:     data.PeriodEnd,
+		UploadID:      upload.ID,
+		SizeBytes:     upload.SizeBytes,
+		TotalValueUSD: data.TotalValueUSD,
+	}
+	replaced, err := s.reportRepo.UpsertStatement(ctx, statement)
+	if err != nil {
+		if err := s.uploadService.Delete(ctx, upload); err != nil {
+			logger.Warn("Failed to delete orphaned statement file", "error", err, "uploadID", upload.ID)
+		}
+		return nil, err
+	}
+	if replaced != nil {
+		s.deleteUpload(ctx, userID, *replaced)
+	}
+
+	logger.Info("Generated statement", "userID", userID, "period", periodStart.Format(statementMonthLayout), "bytes", statement.SizeBytes)
+	return statement, nil
+}
+
+// emailStatement sends the statement's download link if the user wants statements by
+// email and has an address
+func (s *ReportService) emailStatement(ctx context.Context, statement *models.ReportStatement) error {
+	prefs, err := s.reportRepo.GetPreferences(ctx, statement.UserID)
+	if err != nil {
+		return err
+	}
+	if !prefs.EmailEnabled {
+		return nil
+	}
+	user, err := s.userRepo.GetByID(ctx, statement.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil || *user.Email == "" {
+		return nil
+	}
+
+	upload, err := s.uploadService.Get(ctx, statement.UserID, statement.UploadID)
+	if err != nil {
+		return err
+	}
+	link, err := s.uploadService.Link(ctx, upload, statementLinkExpiry)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mailer.SendStatementEmail(ctx, *user.Email, statement, link.DownloadURL); err != nil {
+		return err
+	}
+	return s.reportRepo.MarkStatementEmailed(ctx, statement.ID, s.clock.Now())
+}
+
+// deleteUpload removes the file of a statement that has been regenerated
+func (s *ReportService) deleteUpload(ctx context.Context, userID, uploadID uuid.UUID) {
+	upload, err := s.uploadService.Get(ctx, userID, uploadID)
+	if err == nil {
+		err = s.uploadService.Delete(ctx, upload)
+	}
+	if err != nil {
+		logger.Warn("Failed to delete replaced statement file", "error", err, "uploadID", uploadID)
+	}
+}
+
+// buildStatement gathers a statement's content. Holdings are read at generation time,
+// while realized PnL, income and fees cover the statement period.
+func (s *ReportService) buildStatement(ctx context.Context, userID uuid.UUID, periodStart time.Time) (*models.StatementData, error) {
+	data := &models.StatementData{
+		UserID:      userID,
+		PeriodStart: periodStart,
+		PeriodEnd:   periodStart.AddDate(0, 1, 0),
+		GeneratedAt: s.clock.Now().UTC(),
+		Holdings:    []models.StatementHolding{},
+		RealizedPnL: []models.StatementPnL{},
+		Fees:        []models.StatementFeeSpend{},
+		Income:      []models.StatementIncome{},
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	includeTestnets := blockchain.TestnetMode()
+	if !includeTestnets {
+		wallets = mainnetWallets(wallets)
+	}
+	if err := s.addHoldings(ctx, userID, uniqueAddressWallets(wallets), data); err != nil {
+		return nil, err
+	}
+	if err := s.addRealizedPnL(ctx, userID, wallets, data); err != nil {
+		return nil, err
+	}
+	s.addIncome(ctx, wallets, data)
+
+	fees, err := s.transactionRepo.GetFeeSpend(ctx, userID, data.PeriodStart, data.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	for _, fee := range fees {
+		if !includeTestnets && blockchain.IsTestnet(fee.ChainID) {
+			continue
+		}
+		data.Fees = append(data.Fees, fee)
+		data.TotalFeesUSD = data.TotalFeesUSD.Add(fee.FeeUSD)
+	}
+
+	previous, err := s.reportRepo.GetPreviousStatement(ctx, userID, periodStart)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		opening := previous.TotalValueUSD
+		data.OpeningValueUSD = &opening
+	}
+
+	return data, nil
+}
+
+func (s *ReportService) addHoldings(ctx context.Context, userID uuid.UUID, wallets []*models.Wallet, data *models.StatementData) error {
+	for _, wallet := range wallets {
+		portfolio, err := s.portfolioService.GetMultiChainBalances(ctx, wallet.Address, true, blockchain.TestnetMode(), false, "", "")
+		if err != nil {
+			return fmt.Errorf("failed to get balances for %s: %w", wallet.Address, err)
+		}
+		var networks []*PortfolioBalances
+		for _, balances := range portfolio.ChainBalances {
+			networks = append(networks, balances)
+		}
+		for _, balances := range portfolio.NetworkBalances {
+			networks = append(networks, balances)
+		}
+		for _, balances := range networks {
+			for _, balance := range balances.Balances {
+				if balance.Token == nil {
+					continue
+				}
+				holding := models.StatementHolding{
+					Source:   labelOr(wallet.Label, wallet.Address),
+					Symbol:   balance.Token.Symbol,
+					Quantity: tokenQuantity(balance.Balance, balance.Token.Decimals),
+				}
+				if balance.BalanceUSD != nil {
+					holding.ValueUSD = decimal.NewFromFloat(*balance.BalanceUSD)
+				}
+				data.Holdings = append(data.Holdings, holding)
+			}
+		}
+	}
+
+	bitcoin, err := s.bitcoinService.GetPortfolio(ctx, userID, "")
+	if err != nil {
+		return fmt.Errorf("failed to get bitcoin portfolio: %w", err)
+	}
+	for _, account := range bitcoin.Accounts {
+		if account.Error != "" {
+			return fmt.Errorf("failed to read bitcoin account %s", account.Account.ID)
+		}
+		if account.BalanceSats == 0 {
+			continue
+		}
+		holding := models.StatementHolding{
+			Source:   labelOr(account.Account.Label, account.Account.Identifier),
+			Symbol:   "BTC",
+			Quantity: account.BalanceBTC,
+		}
+		if account.ValueUSD != nil {
+			holding.ValueUSD = decimal.NewFromFloat(*account.ValueUSD)
+		}
+		data.Holdings = append(data.Holdings, holding)
+	}
+
+	exchanges, err := s.exchangeService.GetPortfolio(ctx, userID, "")
+	if err != nil {
+		return fmt.Errorf("failed to get exchange portfolio: %w", err)
+	}
+	for _, account := range exchanges.Accounts {
+		source := exchangeDisplayName(account.Account.Exchange)
+		if account.Account.Label != nil && *account.Account.Label != "" {
+			source += " - " + *account.Account.Label
+		}
+		for _, balance := range account.Balances {
+			holding := models.StatementHolding{
+				Source:   source,
+				Symbol:   balance.Asset,
+				Quantity: balance.Free + balance.Locked,
+			}
+			if balance.ValueUSD != nil {
+				holding.ValueUSD = decimal.NewFromFloat(*balance.ValueUSD)
+			}
+			data.Holdings = append(data.Holdings, holding)
+		}
+	}
+
+	sort.SliceStable(data.Holdings, func(i, j int) bool {
+		return data.Holdings[i].ValueUSD.GreaterThan(data.Holdings[j].ValueUSD)
+	})
+	for _, holding := range data.Holdings {
+		data.TotalValueUSD = data.TotalValueUSD.Add(holding.ValueUSD)
+	}
+	return nil
+}
+
+// addRealizedPnL adds realized PnL over the period for each EVM wallet and the user's
+// Bitcoin accounts. Sources without lots have nothing to report and are skipped.
+func (s *ReportService) addRealizedPnL(ctx context.Context, userID uuid.UUID, wallets []*models.Wallet, data *models.StatementData) error {
+	seen := make(map[string]bool)
+	for _, wallet := range wallets {
+		key := strings.ToLower(wallet.Address)
+		if !wallet.IsEVM() || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		calculation, err := s.pnlService.CalculatePnL(ctx, wallet.Address, data.PeriodStart, data.PeriodEnd, pnl.FIFO, nil)
+		if err != nil {
+			logger.Debug("No PnL for statement", "error", err, "address", wallet.Address)
+			continue
+		}
+		realized, _ := decimal.Parse(calculation.RealizedPnLUSD)
+		if calculation.NFT != nil {
+			realized = realized.Add(decimal.NewFromFloat(calculation.NFT.RealizedPnLUSD))
+		}
+		data.RealizedPnL = append(data.RealizedPnL, models.StatementPnL{
+			Source:         labelOr(wallet.Label, wallet.Address),
+			RealizedPnLUSD: realized,
+		})
+		data.TotalRealizedPnLUSD = data.TotalRealizedPnLUSD.Add(realized)
+	}
+
+	calculation, err := s.bitcoinService.CalculatePnL(ctx, userID, data.PeriodStart, data.PeriodEnd, pnl.FIFO, "")
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == "NOT_FOUND" {
+			return nil
+		}
+		return fmt.Errorf("failed to calculate bitcoin PnL: %w", err)
+	}
+	realized, _ := decimal.Parse(calculation.RealizedPnLUSD)
+	data.RealizedPnL = append(data.RealizedPnL, models.StatementPnL{Source: "Bitcoin", RealizedPnLUSD: realized})
+	data.TotalRealizedPnLUSD = data.TotalRealizedPnLUSD.Add(realized)
+	return nil
+}
+
+// addIncome totals income received over the period by category across EVM wallets, at
+// its value on receipt
+func (s *ReportService) addIncome(ctx context.Context, wallets []*models.Wallet, data *models.StatementData) {
+	byCategory := make(map[string]decimal.Decimal)
+	seen := make(map[string]bool)
+	for _, wallet := range wallets {
+		key := strings.ToLower(wallet.Address)
+		if !wallet.IsEVM() || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		income, err := s.pnlService.CalculateIncome(ctx, wallet.Address, data.PeriodStart, data.PeriodEnd)
+		if err != nil {
+			logger.Debug("No income for statement", "error", err, "address", wallet.Address)
+			continue
+		}
+		for category, value := range income.ByCategory {
+			byCategory[category] = byCategory[category].Add(value)
+		}
+	}
+
+	for category, value := range byCategory {
+		data.Income = append(data.Income, models.StatementIncome{Category: category, ValueUSD: value})
+		data.TotalIncomeUSD = data.TotalIncomeUSD.Add(value)
+	}
+	sort.Slice(data.Income, func(i, j int) bool {
+		if cmp := data.Income[i].ValueUSD.Cmp(data.Income[j].ValueUSD); cmp != 0 {
+			return cmp > 0
+		}
+		return data.Income[i].Category < data.Income[j].Category
+	})
+}
+
+// statementPeriodStart returns the start of the UTC month containing t
+func statementPeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// uniqueAddressWallets returns one wallet per address, as portfolio reads cover every
+// chain an address is on
+func uniqueAddressWallets(wallets []*models.Wallet) []*models.Wallet {
+	seen := make(map[string]bool)
+	var unique []*models.Wallet
+	for _, wallet := range wallets {
+		key := wallet.Address
+		if wallet.IsEVM() {
+			key = strings.ToLower(key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, wallet)
+		}
+	}
+	return unique
+}
+
+// mainnetWallets returns the wallets not flagged as testnet wallets
+func mainnetWallets(wallets []*models.Wallet) []*models.Wallet {
+	var mainnet []*models.Wallet
+	for _, wallet := range wallets {
+		if !wallet.IsTestnet {
+			mainnet = append(mainnet, wallet)
+		}
+	}
+	return mainnet
+}
+
+// exchangeDisplayName capitalizes an exchange identifier, e.g. binance -> Binance
+func exchangeDisplayName(exchange string) string {
+	if exchange == "" {
+		return exchange
+	}
+	return strings.ToUpper(exchange[:1]) + exchange[1:]
+}
+
+// tokenQuantity converts a balance in the token's smallest unit to whole tokens
+func tokenQuantity(balance string, decimals int) float64 {
+	raw, err := amounts.ParseBaseUnits(balance)
+	if err != nil {
+		return 0
+	}
+	return amounts.Float64(raw, decimals)
+}
+
+func labelOr(label *string, fallback string) string {
+	if label != nil && *label != "" {
+		return *label
+	}
+	return fallback
+}
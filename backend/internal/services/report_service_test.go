@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenQuantity(t *testing.T) {
+	assert.InDelta(t, 1.5, tokenQuantity("1500000000000000000", 18), 1e-12)
+	assert.InDelta(t, 250.25, tokenQuantity("250250000", 6), 1e-9)
+	assert.Equal(t, 0.0, tokenQuantity("not a number", 18))
+}
+
+func TestStatementPeriodStart(t *testing.T) {
+	at := time.Date(2026, 3, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600))
+	// 23:30 at UTC-2 is already April in UTC
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), statementPeriodStart(at))
+}
+
+func TestUniqueAddressWallets(t *testing.T) {
+	cosmos := models.ChainRef{Namespace: models.ChainNamespaceCosmos, Reference: "cosmoshub-4"}
+	wallets := []*models.Wallet{
+		{Address: "0xAbC", ChainID: 1},
+		{Address: "0xabc", ChainID: 137},
+		{Address: "cosmos1xyz", Chain: &cosmos},
+	}
+
+	unique := uniqueAddressWallets(wallets)
+	require.Len(t, unique, 2)
+	assert.Equal(t, "0xAbC", unique[0].Address)
+	assert.Equal(t, "cosmos1xyz", unique[1].Address)
+}
+
+func TestMainnetWallets(t *testing.T) {
+	wallets := []*models.Wallet{
+		{Address: "0xabc", ChainID: 1},
+		{Address: "0xabc", ChainID: 80002, IsTestnet: true},
+		{Address: "0xdef", ChainID: 137},
+	}
+
+	mainnet := mainnetWallets(wallets)
+	require.Len(t, mainnet, 2)
+	assert.Equal(t, 1, mainnet[0].ChainID)
+	assert.Equal(t, 137, mainnet[1].ChainID)
+}
+
+func TestGenerateStatement_RejectsIncompleteMonths(t *testing.T) {
+	s := &ReportService{clock: clock.NewFake(time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC))}
+
+	_, err := s.GenerateStatement(context.Background(), uuid.New(), "2026-04")
+	assert.Error(t, err)
+	_, err = s.GenerateStatement(context.Background(), uuid.New(), "April 2026")
+	assert.Error(t, err)
+}
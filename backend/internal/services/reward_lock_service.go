@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultCalendarMonths = 12
+	maxCalendarMonths     = 36
+)
+
+// RewardLockService tracks tokens locked in vote-escrow contracts or vesting on a schedule
+type RewardLockService struct {
+	walletRepo repos.WalletRepository
+	lockRepo   repos.RewardLockRepository
+	clock      clock.Clock
+}
+
+func NewRewardLockService(walletRepo repos.WalletRepository, lockRepo repos.RewardLockRepository) *RewardLockService {
+	return &RewardLockService{
+		walletRepo: walletRepo,
+		lockRepo:   lockRepo,
+		clock:      clock.System,
+	}
+}
+
+// GetLocks returns the user's locks, soonest unlock first, with what each has unlocked so far
+func (s *RewardLockService) GetLocks(ctx context.Context, userID uuid.UUID) ([]*models.RewardLock, error) {
+	locks, err := s.lockRepo.GetByUser(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get reward locks", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch reward locks")
+	}
+
+	now := s.clock.Now()
+	for _, lock := range locks {
+		lock.Unlocked = lock.UnlockedAt(now)
+		lock.NextUnlockAt = lock.NextUnlock(now)
+	}
+	if locks == nil {
+		locks = []*models.RewardLock{}
+	}
+	return locks, nil
+}
+
+// SyncLocks reads the vote-escrow locks of the user's wallets from the chain and returns
+// all their locks. Wallets that can't be read keep the locks stored for them.
+func (s *RewardLockService) SyncLocks(ctx context.Context, userID uuid.UUID, alchemyAPIKey string) ([]*models.RewardLock, error) {
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+
+	seen := make(map[string]bool)
+	var targets []*models.Wallet
+	for _, wallet := range wallets {
+		key := strings.ToLower(wallet.Address)
+		if !wallet.IsEVM() || !blockchain.SupportsVoteEscrows(wallet.ChainID) || seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, wallet)
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+	)
+	for _, wallet := range targets {
+		wg.Add(1)
+		go func(wallet *models.Wallet) {
+			defer wg.Done()
+			locks, err := blockchainService.GetVoteEscrowLocks(ctx, wallet.Address, wallet.ChainID)
+			if err == nil {
+				for _, lock := range locks {
+					lock.UserID = userID
+				}
+				err = s.lockRepo.ReplaceOnchain(ctx, userID, wallet.Address, wallet.ChainID, locks)
+			}
+			if err != nil {
+				logger.Error("Failed to sync vote-escrow locks", "error", err, "address", wallet.Address, "chainID", wallet.ChainID)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}(wallet)
+	}
+	wg.Wait()
+
+	if failed > 0 && failed == len(targets) {
+		return nil, errors.Internal("Failed to read vote-escrow locks")
+	}
+
+	return s.GetLocks(ctx, userID)
+}
+
+// CreateVestingSchedule tracks tokens vesting to one of the user's wallets, such as
+// escrowed rewards
+func (s *RewardLockService) CreateVestingSchedule(ctx context.Context, userID uuid.UUID, req models.CreateVestingScheduleRequest) (*models.RewardLock, error) {
+	if err := validateVestingSchedule(req); err != nil {
+		return nil, err
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+	if !hasWalletAddress(wallets, req.WalletAddress) {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	startsAt := req.StartsAt
+	lock := &models.RewardLock{
+		UserID:        userID,
+		WalletAddress: req.WalletAddress,
+		ChainID:       req.ChainID,
+		Protocol:      req.Protocol,
+		Kind:          models.RewardLockKindVesting,
+		Source:        models.RewardLockSourceManual,
+		Contract:      req.Contract,
+		TokenAddress:  req.TokenAddress,
+		TokenSymbol:   req.TokenSymbol,
+		Amount:        req.Amount,
+		Released:      req.Released,
+		StartsAt:      &startsAt,
+		CliffAt:       req.CliffAt,
+		UnlocksAt:     req.UnlocksAt,
+	}
+	if err := s.lockRepo.Create(ctx, lock); err != nil {
+		logger.Error("Failed to create vesting schedule", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to create vesting schedule")
+	}
+
+	now := s.clock.Now()
+	lock.Unlocked = lock.UnlockedAt(now)
+	lock.NextUnlockAt = lock.NextUnlock(now)
+	return lock, nil
+}
+
+// DeleteLock stops tracking a vesting schedule. On-chain locks go away by syncing once
+// withdrawn.
+func (s *RewardLockService) DeleteLock(ctx context.Context, userID, lockID uuid.UUID) error {
+	lock, err := s.lockRepo.GetByID(ctx, lockID)
+	if err != nil {
+		logger.Error("Failed to get reward lock", "error", err, "lockID", lockID)
+		return errors.Internal("Failed to fetch reward lock")
+	}
+	if lock == nil || lock.UserID != userID {
+		return errors.NotFound("Reward lock")
+	}
+	if lock.Source != models.RewardLockSourceManual {
+		return errors.BadRequest("On-chain locks are removed by syncing once withdrawn")
+	}
+
+	if _, err := s.lockRepo.Delete(ctx, lockID, userID); err != nil {
+		logger.Error("Failed to delete reward lock", "error", err, "lockID", lockID)
+		return errors.Internal("Failed to delete reward lock")
+	}
+	return nil
+}
+
+// GetCalendar lists what the user's locks unlock in each of the coming months, starting
+// with the current one
+func (s *RewardLockService) GetCalendar(ctx context.Context, userID uuid.UUID, months int) ([]models.VestingCalendarMonth, error) {
+	if months == 0 {
+		months = defaultCalendarMonths
+	}
+	if months < 1 || months > maxCalendarMonths {
+		return nil, errors.BadRequest("Months must be between 1 and 36")
+	}
+
+	locks, err := s.lockRepo.GetByUser(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get reward locks", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch reward locks")
+	}
+
+	return vestingCalendar(locks, s.clock.Now().UTC(), months), nil
+}
+
+func validateVestingSchedule(req models.CreateVestingScheduleRequest) error {
+	switch {
+	case !common.IsHexAddress(req.WalletAddress):
+		return errors.BadRequest("Invalid wallet address")
+	case !common.IsHexAddress(req.Contract):
+		return errors.BadRequest("Invalid vesting contract address")
+	case !common.IsHexAddress(req.TokenAddress):
+		return errors.BadRequest("Invalid token address")
+	case req.ChainID <= 0:
+		return errors.BadRequest("Invalid chain ID")
+	case req.Protocol == "" || len(req.Protocol) > 50:
+		return errors.BadRequest("Protocol is required and at most 50 characters")
+	case req.TokenSymbol == "" || len(req.TokenSymbol) > 20:
+		return errors.BadRequest("Token symbol is required and at most 20 characters")
+	case req.Amount <= 0:
+		return errors.BadRequest("Amount must be greater than 0")
+	case req.Released < 0 || req.Released > req.Amount:
+		return errors.BadRequest("Released must be between 0 and the amount")
+	case !req.UnlocksAt.After(req.StartsAt):
+		return errors.BadRequest("Vesting must end after it starts")
+	case req.CliffAt != nil && (req.CliffAt.Before(req.StartsAt) || req.CliffAt.After(req.UnlocksAt)):
+		return errors.BadRequest("Cliff must be between the vesting start and end")
+	}
+	return nil
+}
+
+// vestingCalendar buckets what each lock unlocks into calendar months, from the month of
+// from onwards. Amounts unlocking before from are left out.
+func vestingCalendar(locks []*models.RewardLock, from time.Time, months int) []models.VestingCalendarMonth {
+	monthStart := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	calendar := make([]models.VestingCalendarMonth, months)
+	windowStart := from
+	for m := range calendar {
+		windowEnd := monthStart.AddDate(0, m+1, 0)
+		month := models.VestingCalendarMonth{
+			Month:          monthStart.AddDate(0, m, 0).Format("2006-01"),
+			Unlocks:        []models.RewardUnlock{},
+			TotalsBySymbol: map[string]float64{},
+		}
+
+		for _, lock := range locks {
+			// Unlocks in (windowStart, windowEnd]
+			amount := lock.UnlockedAt(windowEnd) - lock.UnlockedAt(windowStart)
+			if amount <= 0 {
+				continue
+			}
+			unlock := models.RewardUnlock{
+				LockID:      lock.ID,
+				Protocol:    lock.Protocol,
+				Kind:        lock.Kind,
+				TokenSymbol: lock.TokenSymbol,
+				Amount:      amount,
+			}
+			if lock.Kind == models.RewardLockKindVoteEscrow {
+				unlock.At = &lock.UnlocksAt
+			} else if lock.CliffAt != nil && lock.CliffAt.After(windowStart) && !lock.CliffAt.After(windowEnd) {
+				unlock.At = lock.CliffAt
+			}
+			month.Unlocks = append(month.Unlocks, unlock)
+			month.TotalsBySymbol[lock.TokenSymbol] += amount
+		}
+
+		calendar[m] = month
+		windowStart = windowEnd
+	}
+	return calendar
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVestingCalendar(t *testing.T) {
+	from := time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cliff := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	vesting := &models.RewardLock{
+		ID: uuid.New(), Kind: models.RewardLockKindVesting, TokenSymbol: "GMX",
+		Amount: 90, StartsAt: &start, CliffAt: &cliff, UnlocksAt: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	escrow := &models.RewardLock{
+		ID: uuid.New(), Kind: models.RewardLockKindVoteEscrow, TokenSymbol: "CRV",
+		Amount: 1000, UnlocksAt: time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC),
+	}
+	expired := &models.RewardLock{ID: uuid.New(), Kind: models.RewardLockKindVoteEscrow, TokenSymbol: "CRV", Amount: 5, UnlocksAt: start}
+
+	calendar := vestingCalendar([]*models.RewardLock{vesting, escrow, expired}, from, 4)
+	require.Len(t, calendar, 4)
+	assert.Equal(t, []string{"2025-01", "2025-02", "2025-03", "2025-04"},
+		[]string{calendar[0].Month, calendar[1].Month, calendar[2].Month, calendar[3].Month})
+
+	// The cliff at midnight on February 1st releases January's vesting at once
+	require.Len(t, calendar[0].Unlocks, 1)
+	jan := calendar[0].Unlocks[0]
+	assert.InDelta(t, 31, jan.Amount, 1e-9)
+	assert.Equal(t, &cliff, jan.At)
+
+	// Then it vests linearly, and the escrow unlocks in full
+	require.Len(t, calendar[1].Unlocks, 1)
+	assert.InDelta(t, 28, calendar[1].Unlocks[0].Amount, 1e-9)
+	assert.Nil(t, calendar[1].Unlocks[0].At)
+	require.Len(t, calendar[2].Unlocks, 2)
+	assert.InDelta(t, 31, calendar[2].TotalsBySymbol["GMX"], 1e-9)
+	assert.Equal(t, 1000.0, calendar[2].TotalsBySymbol["CRV"])
+	assert.Equal(t, &escrow.UnlocksAt, calendar[2].Unlocks[1].At)
+	assert.Empty(t, calendar[3].Unlocks)
+}
+
+func TestValidateVestingSchedule(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := models.CreateVestingScheduleRequest{
+		WalletAddress: "0x1111111111111111111111111111111111111111",
+		ChainID:       42161,
+		Protocol:      "gmx",
+		Contract:      "0x2222222222222222222222222222222222222222",
+		TokenAddress:  "0x3333333333333333333333333333333333333333",
+		TokenSymbol:   "GMX",
+		Amount:        100,
+		StartsAt:      start,
+		UnlocksAt:     start.AddDate(1, 0, 0),
+	}
+	assert.NoError(t, validateVestingSchedule(valid))
+
+	backwards := valid
+	backwards.UnlocksAt = start
+	assert.Error(t, validateVestingSchedule(backwards))
+
+	lateCliff := valid
+	cliff := start.AddDate(2, 0, 0)
+	lateCliff.CliffAt = &cliff
+	assert.Error(t, validateVestingSchedule(lateCliff))
+
+	overReleased := valid
+	overReleased.Released = 101
+	assert.Error(t, validateVestingSchedule(overReleased))
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxSavedSearches caps how many searches one user can save
+	maxSavedSearches = 50
+	// maxSavedSearchName is the longest name a saved search can have, in characters
+	maxSavedSearchName = 100
+)
+
+// SavedSearchService keeps users' named filter sets for the yield explorer and the
+// transaction list. A search can be shared through a random token, which reveals its
+// name and filters but not its owner.
+type SavedSearchService struct {
+	repo repos.SavedSearchRepository
+}
+
+func NewSavedSearchService(repo repos.SavedSearchRepository) *SavedSearchService {
+	return &SavedSearchService{repo: repo}
+}
+
+func (s *SavedSearchService) Create(ctx context.Context, userID uuid.UUID, req *models.CreateSavedSearchRequest) (*models.SavedSearch, error) {
+	name, err := validateSavedSearchName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	params, err := validateSavedSearchParams(req.Kind, req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByUser(ctx, userID, "")
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if len(existing) >= maxSavedSearches {
+		return nil, errors.BadRequest("Saved search limit reached; delete one to save another")
+	}
+
+	search := &models.SavedSearch{
+		UserID: userID,
+		Name:   name,
+		Kind:   req.Kind,
+		Params: params,
+	}
+	if req.Shared {
+		if search.ShareToken, err = newShareToken(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Create(ctx, search); err != nil {
+		return nil, savedSearchSaveError(err)
+	}
+	return search, nil
+}
+
+// List returns the user's saved searches, of one kind unless kind is empty
+func (s *SavedSearchService) List(ctx context.Context, userID uuid.UUID, kind string) ([]*models.SavedSearch, error) {
+	if kind != "" && kind != models.SavedSearchKindYieldPools && kind != models.SavedSearchKindTransactions {
+		return nil, errors.BadRequest("Kind must be yield_pools or transactions")
+	}
+	searches, err := s.repo.GetByUser(ctx, userID, kind)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return searches, nil
+}
+
+func (s *SavedSearchService) Get(ctx context.Context, userID, id uuid.UUID) (*models.SavedSearch, error) {
+	search, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if search == nil || search.UserID != userID {
+		return nil, errors.NotFound("Saved search")
+	}
+	return search, nil
+}
+
+// GetShared returns the saved search shared with the token
+func (s *SavedSearchService) GetShared(ctx context.Context, token string) (*models.SharedSavedSearch, error) {
+	if token == "" {
+		return nil, errors.NotFound("Saved search")
+	}
+	search, err := s.repo.GetByShareToken(ctx, token)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if search == nil {
+		return nil, errors.NotFound("Saved search")
+	}
+	return &models.SharedSavedSearch{
+		Name:   search.Name,
+		Kind:   search.Kind,
+		Params: search.Params,
+	}, nil
+}
+
+func (s *SavedSearchService) Update(ctx context.Context, userID, id uuid.UUID, req *models.UpdateSavedSearchRequest) (*models.SavedSearch, error) {
+	search, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if search.Name, err = validateSavedSearchName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Params != nil {
+		if search.Params, err = validateSavedSearchParams(search.Kind, *req.Params); err != nil {
+			return nil, err
+		}
+	}
+	if req.Shared != nil {
+		switch {
+		case !*req.Shared:
+			search.ShareToken = nil
+		case search.ShareToken == nil:
+			if search.ShareToken, err = newShareToken(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.repo.Update(ctx, search); err != nil {
+		return nil, savedSearchSaveError(err)
+	}
+	return search, nil
+}
+
+func (s *SavedSearchService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	deleted, err := s.repo.Delete(ctx, id, userID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Saved search")
+	}
+	return nil
+}
+
+func validateSavedSearchName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.BadRequest("Name is required")
+	}
+	if utf8.RuneCountInString(name) > maxSavedSearchName {
+		return "", errors.BadRequest("Name must be at most 100 characters")
+	}
+	return name, nil
+}
+
+// validateSavedSearchParams checks params are ones the kind's search endpoint accepts,
+// with values it accepts, and drops empty ones
+func validateSavedSearchParams(kind string, params map[string]string) (map[string]string, error) {
+	cleaned := make(map[string]string, len(params))
+	for key, value := range params {
+		if value = strings.TrimSpace(value); value != "" {
+			cleaned[key] = value
+		}
+	}
+
+	switch kind {
+	case models.SavedSearchKindYieldPools:
+		for key := range cleaned {
+			if !yieldSearchParams[key] {
+				return nil, errors.BadRequest("Unknown yield search parameter " + key)
+			}
+		}
+		search, err := YieldPoolSearchFromParams(cleaned)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateYieldPoolSearch(search); err != nil {
+			return nil, err
+		}
+	case models.SavedSearchKindTransactions:
+		for key := range cleaned {
+			if key != "q" {
+				return nil, errors.BadRequest("Unknown transaction search parameter " + key)
+			}
+		}
+		if _, err := parseTransactionQuery(cleaned["q"]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.BadRequest("Kind must be yield_pools or transactions")
+	}
+
+	return cleaned, nil
+}
+
+func savedSearchSaveError(err error) error {
+	if err == repos.ErrSavedSearchNameTaken {
+		return errors.New("SAVED_SEARCH_EXISTS", "A saved search with this name already exists", 409)
+	}
+	return errors.DatabaseError(err)
+}
+
+// newShareToken returns a random URL-safe token for a share link
+func newShareToken() (*string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Internal("Failed to generate share token")
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return &token, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYieldPoolSearchFromParams(t *testing.T) {
+	search, err := YieldPoolSearchFromParams(map[string]string{
+		"q":          "usdc aave",
+		"chains":     "1, 137,",
+		"risk":       "low,medium",
+		"protocols":  "aave-v3",
+		"minApy":     "2.5",
+		"maxTvl":     "1000000",
+		"stablecoin": "true",
+		"active":     "false",
+		"sort":       "tvl",
+		"order":      "asc",
+		"limit":      "5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "usdc aave", search.Text)
+	assert.Equal(t, []int{1, 137}, search.ChainIDs)
+	assert.Equal(t, []string{"low", "medium"}, search.RiskLevels)
+	assert.Equal(t, []string{"aave-v3"}, search.ProtocolSlugs)
+	assert.Equal(t, 2.5, *search.MinAPY)
+	assert.Nil(t, search.MaxAPY)
+	assert.Equal(t, 1000000.0, *search.MaxTVL)
+	assert.True(t, search.StablecoinOnly)
+	assert.False(t, *search.IsActive)
+	assert.Equal(t, "tvl", search.SortBy)
+	assert.True(t, search.SortAsc)
+	// Paging is left to the caller
+	assert.Zero(t, search.Limit)
+
+	search, err = YieldPoolSearchFromParams(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "apy", search.SortBy)
+	assert.False(t, search.SortAsc)
+	assert.Nil(t, search.IsActive)
+
+	for _, params := range []map[string]string{
+		{"chains": "1,eth"},
+		{"minApy": "high"},
+		{"stablecoin": "yes please"},
+		{"active": "maybe"},
+	} {
+		_, err := YieldPoolSearchFromParams(params)
+		assert.Error(t, err, params)
+	}
+}
+
+func TestValidateSavedSearchParams(t *testing.T) {
+	params, err := validateSavedSearchParams(models.SavedSearchKindYieldPools, map[string]string{
+		"q":      " eth ",
+		"risk":   "low",
+		"minApy": "",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"q": "eth", "risk": "low"}, params)
+
+	params, err = validateSavedSearchParams(models.SavedSearchKindTransactions, map[string]string{"q": "chain:1 type:swap"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"q": "chain:1 type:swap"}, params)
+
+	// An empty filter set is a valid search of everything
+	params, err = validateSavedSearchParams(models.SavedSearchKindTransactions, nil)
+	require.NoError(t, err)
+	assert.Empty(t, params)
+
+	for _, tc := range []struct {
+		kind   string
+		params map[string]string
+	}{
+		{"wallets", nil},
+		{models.SavedSearchKindYieldPools, map[string]string{"limit": "10"}},
+		{models.SavedSearchKindYieldPools, map[string]string{"sort": "popularity"}},
+		{models.SavedSearchKindYieldPools, map[string]string{"risk": "extreme"}},
+		{models.SavedSearchKindYieldPools, map[string]string{"minApy": "10", "maxApy": "5"}},
+		{models.SavedSearchKindTransactions, map[string]string{"chainId": "1"}},
+		{models.SavedSearchKindTransactions, map[string]string{"q": "status:lost"}},
+	} {
+		_, err := validateSavedSearchParams(tc.kind, tc.params)
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr, tc.params)
+		assert.Equal(t, 400, appErr.Status)
+	}
+}
+
+func TestValidateSavedSearchName(t *testing.T) {
+	name, err := validateSavedSearchName("  Stable farms ")
+	require.NoError(t, err)
+	assert.Equal(t, "Stable farms", name)
+
+	_, err = validateSavedSearchName("   ")
+	assert.Error(t, err)
+
+	long := make([]rune, 101)
+	for i := range long {
+		long[i] = 'é'
+	}
+	_, err = validateSavedSearchName(string(long[:100]))
+	assert.NoError(t, err)
+	_, err = validateSavedSearchName(string(long))
+	assert.Error(t, err)
+}
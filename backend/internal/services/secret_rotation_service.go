@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// SecretRotationService moves the secrets users stored onto the current encryption key.
+// After ENCRYPTION_KEY is replaced, with the old key kept in ENCRYPTION_PREVIOUS_KEYS,
+// a rotation rewraps every value so the old key can be dropped. It also seals webhook
+// URLs and report secrets stored before encryption was turned on.
+type SecretRotationService struct {
+	secretRepo repos.SecretRepository
+	encryptor  *crypto.Encryptor
+}
+
+// NewSecretRotationService creates the rotation service; a nil encryptor disables it
+func NewSecretRotationService(secretRepo repos.SecretRepository, encryptor *crypto.Encryptor) *SecretRotationService {
+	return &SecretRotationService{secretRepo: secretRepo, encryptor: encryptor}
+}
+
+// Rotate re-seals every stored secret that isn't sealed with the current key. It can be
+// run again after a partial failure; values already rotated are left alone.
+func (s *SecretRotationService) Rotate(ctx context.Context) (*models.SecretRotationResult, error) {
+	if s.encryptor == nil {
+		return nil, errors.BadRequest("Encryption is not configured; set ENCRYPTION_KEY")
+	}
+
+	var result models.SecretRotationResult
+	var err error
+	if result.APIKeys, err = s.secretRepo.RotateAPIKeys(ctx, s.encryptor); err != nil {
+		return nil, s.failed(err)
+	}
+	if result.ExchangeAccounts, err = s.secretRepo.RotateExchangeCredentials(ctx, s.encryptor); err != nil {
+		return nil, s.failed(err)
+	}
+	if result.Alerts, err = s.secretRepo.RotateAlertNotifications(ctx, s.encryptor); err != nil {
+		return nil, s.failed(err)
+	}
+	if result.OutboxNotifications, err = s.secretRepo.RotateOutboxNotifications(ctx, s.encryptor); err != nil {
+		return nil, s.failed(err)
+	}
+	if result.WebhookReports, err = s.secretRepo.RotateWebhookReports(ctx, s.encryptor); err != nil {
+		return nil, s.failed(err)
+	}
+
+	logger.Info("Rotated stored secrets", "apiKeys", result.APIKeys, "exchangeAccounts", result.ExchangeAccounts, "alerts", result.Alerts,
+		"outboxNotifications", result.OutboxNotifications, "webhookReports", result.WebhookReports)
+	return &result, nil
+}
+
+func (s *SecretRotationService) failed(err error) error {
+	logger.Error("Failed to rotate stored secrets", "error", err)
+	return errors.Internal("Failed to rotate stored secrets")
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySecretRepo struct {
+	apiKeys       [][]byte
+	webhook       string
+	queuedWebhook string
+}
+
+func (r *memorySecretRepo) RotateAPIKeys(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	rotated := 0
+	for i, sealed := range r.apiKeys {
+		resealed, changed, err := encryptor.Rotate(sealed)
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			r.apiKeys[i] = resealed
+			rotated++
+		}
+	}
+	return rotated, nil
+}
+
+func (r *memorySecretRepo) RotateExchangeCredentials(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	return 0, nil
+}
+
+func (r *memorySecretRepo) RotateAlertNotifications(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	resealed, changed, err := encryptor.RotateString(r.webhook)
+	if err != nil || !changed {
+		return 0, err
+	}
+	r.webhook = resealed
+	return 1, nil
+}
+
+func (r *memorySecretRepo) RotateOutboxNotifications(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	resealed, changed, err := encryptor.RotateString(r.queuedWebhook)
+	if err != nil || !changed {
+		return 0, err
+	}
+	r.queuedWebhook = resealed
+	return 1, nil
+}
+
+func (r *memorySecretRepo) RotateWebhookReports(ctx context.Context, encryptor *crypto.Encryptor) (int, error) {
+	return 0, nil
+}
+
+func TestSecretRotationService(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	old, err := crypto.NewEncryptor(oldKey)
+	require.NoError(t, err)
+	sealed, err := old.Encrypt([]byte("alchemy-key"))
+	require.NoError(t, err)
+
+	queued, err := old.EncryptString("https://example.com/hook?token=abc")
+	require.NoError(t, err)
+
+	repo := &memorySecretRepo{apiKeys: [][]byte{sealed}, webhook: "https://example.com/hook?token=abc", queuedWebhook: queued}
+	keyring, err := crypto.NewKeyring(newKey, oldKey)
+	require.NoError(t, err)
+	service := NewSecretRotationService(repo, keyring)
+
+	result, err := service.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.APIKeys)
+	assert.Equal(t, 1, result.Alerts, "plaintext webhooks get sealed")
+	assert.Equal(t, 1, result.OutboxNotifications, "queued notifications are rotated with their alerts")
+
+	// Rotation can be run again safely, and the old key is no longer needed
+	result, err = service.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.APIKeys+result.Alerts+result.OutboxNotifications)
+	current, err := crypto.NewEncryptor(newKey)
+	require.NoError(t, err)
+	plain, err := current.Decrypt(repo.apiKeys[0])
+	require.NoError(t, err)
+	assert.Equal(t, "alchemy-key", string(plain))
+	webhook, err := current.DecryptString(repo.webhook)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook?token=abc", webhook)
+	webhook, err = current.DecryptString(repo.queuedWebhook)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook?token=abc", webhook)
+
+	_, err = NewSecretRotationService(repo, nil).Rotate(ctx)
+	assert.Error(t, err)
+}
@@ -4,22 +4,44 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	stderrors "errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
 	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spruceid/siwe-go"
 )
 
+// ContractSignatureChecker asks a contract wallet whether it accepts a signature, following
+// EIP-1271
+type ContractSignatureChecker interface {
+	IsValidSignature(ctx context.Context, chainID int, account string, hash common.Hash, signature []byte) (bool, error)
+}
+
+// PlatformContractSignatures checks contract wallet signatures with the platform's
+// provider keys, as sign-in comes before any user keys. Rotated keys apply to the next check.
+type PlatformContractSignatures struct{}
+
+func (PlatformContractSignatures) IsValidSignature(ctx context.Context, chainID int, account string, hash common.Hash, signature []byte) (bool, error) {
+	return blockchain.NewBlockchainServiceWithDynamicKeys("", "").IsValidSignature(ctx, chainID, account, hash, signature)
+}
+
 type SIWEService struct {
 	userRepo  repos.UserRepository
 	nonceRepo repos.NonceRepository
 	domain    string
+	// contractSignatures verifies signatures of contract wallets; without it only EOAs
+	// can sign in
+	contractSignatures ContractSignatureChecker
+	clock              clock.Clock
 }
 
 func NewSIWEService(userRepo repos.UserRepository, nonceRepo repos.NonceRepository, domain string) *SIWEService {
@@ -27,9 +49,18 @@ func NewSIWEService(userRepo repos.UserRepository, nonceRepo repos.NonceReposito
 		userRepo:  userRepo,
 		nonceRepo: nonceRepo,
 		domain:    domain,
+		clock:     clock.System,
 	}
 }
 
+// NewSIWEServiceWithContractWallets creates a service that also signs in Safes and other
+// smart accounts, verifying their signatures with EIP-1271 on the chain the message names
+func NewSIWEServiceWithContractWallets(userRepo repos.UserRepository, nonceRepo repos.NonceRepository, domain string, contractSignatures ContractSignatureChecker) *SIWEService {
+	s := NewSIWEService(userRepo, nonceRepo, domain)
+	s.contractSignatures = contractSignatures
+	return s
+}
+
 // GenerateNonce creates a new nonce for SIWE authentication
 func (s *SIWEService) GenerateNonce(ctx context.Context, address string) (string, error) {
 	// Validate Ethereum address
@@ -48,7 +79,7 @@ func (s *SIWEService) GenerateNonce(ctx context.Context, address string) (string
 	nonce := hex.EncodeToString(b)
 
 	// Store nonce with 10 minute expiration
-	expiresAt := time.Now().Add(10 * time.Minute)
+	expiresAt := s.clock.Now().Add(10 * time.Minute)
 	if err := s.nonceRepo.Store(ctx, address, nonce, expiresAt); err != nil {
 		return "", errors.Internal("Failed to store nonce")
 	}
@@ -90,12 +121,21 @@ func (s *SIWEService) VerifySignature(ctx context.Context, message, signature st
 
 	// Verify signature
 	if err := s.verifyEthereumSignature(message, signature, address); err != nil {
-		return nil, errors.Unauthorized("Invalid signature")
+		valid, err := s.verifyContractSignature(ctx, message, signature, address, siweMessage.GetChainID())
+		if err != nil {
+			return nil, err
+		}
+		if !valid {
+			return nil, errors.Unauthorized("Invalid signature")
+		}
 	}
 
 	// Get or create user
 	user, err := s.userRepo.GetByAddress(ctx, address)
 	if err != nil {
+		if !stderrors.Is(err, repos.ErrNotFound) {
+			return nil, errors.DatabaseError(err)
+		}
 		// User not found, create new one
 		user, err = s.userRepo.Create(ctx, address, "")
 		if err != nil {
@@ -104,7 +144,7 @@ func (s *SIWEService) VerifySignature(ctx context.Context, message, signature st
 	}
 
 	// Update last login
-	now := time.Now()
+	now := s.clock.Now()
 	user.LastLoginAt = &now
 	if err := s.userRepo.UpdateLastLogin(ctx, user.ID, now); err != nil {
 		// Log error but don't fail auth
@@ -136,11 +176,8 @@ func (s *SIWEService) verifyEthereumSignature(message, signature, expectedAddres
 		sigBytes[64] -= 27
 	}
 
-	// Hash the message with Ethereum prefix
-	messageHash := crypto.Keccak256Hash([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
-
 	// Recover public key from signature
-	pubKey, err := crypto.SigToPub(messageHash.Bytes(), sigBytes)
+	pubKey, err := crypto.SigToPub(personalMessageHash(message).Bytes(), sigBytes)
 	if err != nil {
 		return fmt.Errorf("failed to recover public key: %w", err)
 	}
@@ -156,8 +193,44 @@ func (s *SIWEService) verifyEthereumSignature(message, signature, expectedAddres
 	return nil
 }
 
-// GenerateSIWEMessage creates a SIWE message for signing
+// verifyContractSignature checks a signature that no key of the address made against the
+// wallet contract at the address, on the chain the message was signed for. Safes and smart
+// accounts sign this way; for an EOA the check simply fails.
+func (s *SIWEService) verifyContractSignature(ctx context.Context, message, signature, address string, chainID int) (bool, error) {
+	if s.contractSignatures == nil {
+		return false, nil
+	}
+	if !blockchain.IsSupportedChain(chainID) {
+		return false, errors.BadRequest(fmt.Sprintf("Contract wallet signatures can't be verified on chain %d", chainID))
+	}
+
+	sigBytes, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sigBytes) == 0 {
+		return false, nil
+	}
+
+	valid, err := s.contractSignatures.IsValidSignature(ctx, chainID, address, personalMessageHash(message), sigBytes)
+	if err != nil {
+		logger.Error("Failed to verify contract wallet signature", "error", err, "address", address, "chainId", chainID)
+		return false, errors.Internal("Failed to verify contract wallet signature")
+	}
+	return valid, nil
+}
+
+// personalMessageHash hashes a message the way personal_sign does, with the Ethereum
+// signed message prefix
+func personalMessageHash(message string) common.Hash {
+	return crypto.Keccak256Hash([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+}
+
+// GenerateSIWEMessage creates a SIWE message for signing on mainnet
 func (s *SIWEService) GenerateSIWEMessage(address, nonce string) (string, error) {
+	return s.GenerateSIWEMessageForChain(address, nonce, 1)
+}
+
+// GenerateSIWEMessageForChain creates a SIWE message for signing on the given chain.
+// Contract wallets are verified on that chain, so it should be one they're deployed on.
+func (s *SIWEService) GenerateSIWEMessageForChain(address, nonce string, chainID int) (string, error) {
 	// Validate address
 	if !common.IsHexAddress(address) {
 		return "", errors.BadRequest("Invalid Ethereum address")
@@ -172,8 +245,8 @@ func (s *SIWEService) GenerateSIWEMessage(address, nonce string) (string, error)
 		map[string]interface{}{
 			"statement": "Sign in to DeFi Portfolio Dashboard",
 			"version":   "1",
-			"chainId":   1,
-			"issuedAt":  time.Now().Format(time.RFC3339),
+			"chainId":   chainID,
+			"issuedAt":  s.clock.Now().Format(time.RFC3339),
 		},
 	)
 	if err != nil {
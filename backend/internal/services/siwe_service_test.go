@@ -111,8 +111,8 @@ func TestSIWEService_GenerateNonce(t *testing.T) {
 	ctx := context.Background()
 	address := "0x742d35Cc6573C42c8Ee90b4E43e04c1Fe9E2395d"
 
-	// Mock successful nonce storage
-	nonceRepo.On("Store", ctx, address, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+	// Mock successful nonce storage under the checksummed address
+	nonceRepo.On("Store", ctx, common.HexToAddress(address).Hex(), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
 
 	nonce, err := service.GenerateNonce(ctx, address)
 
@@ -151,7 +151,7 @@ func TestSIWEService_ValidateAddress(t *testing.T) {
 		{"Valid address lowercase", "0x742d35cc6573c42c8ee90b4e43e04c1fe9e2395d", true},
 		{"Invalid address", "invalid-address", false},
 		{"Empty address", "", false},
-		{"Address without 0x", "742d35Cc6573C42c8Ee90b4E43e04c1Fe9E2395d", false},
+		{"Address without 0x", "742d35Cc6573C42c8Ee90b4E43e04c1Fe9E2395d", true},
 	}
 
 	for _, tt := range tests {
@@ -175,7 +175,7 @@ func TestSIWEService_GenerateSIWEMessage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, message)
 	assert.Contains(t, message, "localhost")
-	assert.Contains(t, message, address)
+	assert.Contains(t, message, common.HexToAddress(address).Hex())
 	assert.Contains(t, message, nonce)
 	assert.Contains(t, message, "Sign in to DeFi Portfolio Dashboard")
 }
@@ -193,7 +193,7 @@ func TestSIWEService_VerifyEthereumSignature(t *testing.T) {
 	message := "Test message for signing"
 
 	// Sign the message
-	messageHash := crypto.Keccak256Hash([]byte("\\x19Ethereum Signed Message:\\n" + "25" + message))
+	messageHash := personalMessageHash(message)
 	signature, err := crypto.Sign(messageHash.Bytes(), privateKey)
 	assert.NoError(t, err)
 	
@@ -214,4 +214,39 @@ func TestSIWEService_VerifyEthereumSignature(t *testing.T) {
 	// Test with invalid signature
 	err = service.verifyEthereumSignature(message, "invalid-signature", testAddress)
 	assert.Error(t, err)
-}
\ No newline at end of file
+}
+
+// fakeContractSignatures accepts one signature per account
+type fakeContractSignatures struct {
+	accepted map[string][]byte
+	checked  common.Hash
+}
+
+func (f *fakeContractSignatures) IsValidSignature(ctx context.Context, chainID int, account string, hash common.Hash, signature []byte) (bool, error) {
+	f.checked = hash
+	return string(f.accepted[account]) == string(signature), nil
+}
+
+func TestSIWEService_VerifyContractSignature(t *testing.T) {
+	safe := "0x1111111111111111111111111111111111111111"
+	checker := &fakeContractSignatures{accepted: map[string][]byte{safe: {0xaa, 0xbb}}}
+	service := NewSIWEServiceWithContractWallets(new(MockUserRepository), new(MockNonceRepository), "localhost", checker)
+	ctx := context.Background()
+
+	valid, err := service.verifyContractSignature(ctx, "hello", "0xaabb", safe, 1)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, personalMessageHash("hello"), checker.checked)
+
+	valid, err = service.verifyContractSignature(ctx, "hello", "0xaacc", safe, 1)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	_, err = service.verifyContractSignature(ctx, "hello", "0xaabb", safe, 999999)
+	assert.Error(t, err, "chains without an RPC endpoint can't verify")
+
+	eoaOnly := NewSIWEService(new(MockUserRepository), new(MockNonceRepository), "localhost")
+	valid, err = eoaOnly.verifyContractSignature(ctx, "hello", "0xaabb", safe, 1)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	gweiPerETH = 1e9
+	// unitsTolerance is the relative change in units below which a holding is unchanged,
+	// absorbing share rounding on stETH transfers
+	unitsTolerance = 1e-9
+	// validatorStatusUnavailable marks validators whose beacon chain state couldn't be read
+	validatorStatusUnavailable = "unavailable"
+)
+
+type StakingService struct {
+	walletRepo   repos.WalletRepository
+	stakingRepo  repos.StakingRepository
+	beaconClient *external.BeaconchainClient
+}
+
+func NewStakingService(walletRepo repos.WalletRepository, stakingRepo repos.StakingRepository, beaconClient *external.BeaconchainClient) *StakingService {
+	return &StakingService{
+		walletRepo:   walletRepo,
+		stakingRepo:  stakingRepo,
+		beaconClient: beaconClient,
+	}
+}
+
+// GetPositions returns the user's ETH staking: liquid staking tokens across their wallets
+// and, unless address narrows the result to one wallet, their tracked validators
+func (s *StakingService) GetPositions(ctx context.Context, userID uuid.UUID, address string, alchemyAPIKey, coinGeckoAPIKey string) (*models.StakingPositions, error) {
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+
+	// Wallets are per chain, but liquid staking tokens are only read on mainnet, so each
+	// address is read once
+	seen := make(map[string]bool)
+	var targets []string
+	for _, wallet := range wallets {
+		if !wallet.IsEVM() {
+			continue
+		}
+		key := strings.ToLower(wallet.Address)
+		if address != "" && key != strings.ToLower(address) {
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, wallet.Address)
+		}
+	}
+	if address != "" && len(targets) == 0 {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, coinGeckoAPIKey)
+
+	result := &models.StakingPositions{
+		LiquidStaking: []*models.LiquidStakingPosition{},
+		Validators:    []*models.ValidatorPosition{},
+	}
+	failed := 0
+	for _, target := range targets {
+		positions, err := s.SyncLiquidStaking(ctx, blockchainService, target, blockchain.ChainIDEthereum)
+		if err != nil {
+			logger.Error("Failed to read liquid staking positions", "error", err, "address", target)
+			failed++
+			continue
+		}
+		result.LiquidStaking = append(result.LiquidStaking, positions...)
+	}
+	if failed > 0 && failed == len(targets) {
+		return nil, errors.Internal("Failed to read staking positions")
+	}
+	sort.SliceStable(result.LiquidStaking, func(i, j int) bool {
+		return result.LiquidStaking[i].UnderlyingETH > result.LiquidStaking[j].UnderlyingETH
+	})
+
+	if address == "" {
+		validators, err := s.getValidatorPositions(ctx, userID)
+		if err != nil {
+			logger.Error("Failed to get staking validators", "error", err, "userID", userID)
+			return nil, errors.Internal("Failed to fetch validators")
+		}
+		result.Validators = validators
+	}
+
+	var ethPrice *float64
+	if price, err := blockchainService.GetETHPriceUSD(ctx); err != nil {
+		logger.Warn("Failed to get ETH price, staking positions are reported in ETH only", "error", err)
+	} else {
+		ethPrice = &price
+	}
+	summarizeStaking(result, ethPrice)
+
+	return result, nil
+}
+
+// SyncLiquidStaking reads a wallet's liquid staking tokens and rolls their stored principal
+// forward: added units join the principal at the current rate, removed units take their
+// share of it with them, and everything above the principal is accrued yield
+func (s *StakingService) SyncLiquidStaking(ctx context.Context, blockchainService *blockchain.BlockchainService, address string, chainID int) ([]*models.LiquidStakingPosition, error) {
+	states, err := s.stakingRepo.GetLiquidStakingStates(ctx, address, chainID)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := blockchainService.GetLiquidStakingPositions(ctx, address, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, position := range positions {
+		key := strings.ToLower(position.Token)
+		state := &models.LiquidStakingState{
+			WalletAddress: address,
+			ChainID:       chainID,
+			Token:         position.Token,
+			Units:         position.Units,
+			PrincipalETH:  rollPrincipal(states[key], position.Units, position.ExchangeRate),
+		}
+		if err := s.stakingRepo.UpsertLiquidStakingState(ctx, state); err != nil {
+			return nil, err
+		}
+		delete(states, key)
+
+		position.PrincipalETH = state.PrincipalETH
+		position.AccruedETH = position.UnderlyingETH - state.PrincipalETH
+		position.TrackedSince = state.TrackedSince
+	}
+
+	// Holdings no longer present were exited
+	for _, state := range states {
+		if err := s.stakingRepo.DeleteLiquidStakingState(ctx, address, chainID, state.Token); err != nil {
+			return nil, err
+		}
+	}
+
+	return positions, nil
+}
+
+// AddValidator tracks a beacon chain validator for the user
+func (s *StakingService) AddValidator(ctx context.Context, userID uuid.UUID, validatorIndex int64, label *string) (*models.StakingValidator, error) {
+	if validatorIndex < 0 {
+		return nil, errors.BadRequest("Validator index must not be negative")
+	}
+
+	validator := &models.StakingValidator{
+		UserID:         userID,
+		ValidatorIndex: validatorIndex,
+		Label:          label,
+	}
+	if err := s.stakingRepo.AddValidator(ctx, validator); err != nil {
+		logger.Error("Failed to add staking validator", "error", err, "userID", userID, "validatorIndex", validatorIndex)
+		return nil, errors.Internal("Failed to add validator")
+	}
+
+	return validator, nil
+}
+
+// RemoveValidator stops tracking a validator
+func (s *StakingService) RemoveValidator(ctx context.Context, userID uuid.UUID, validatorIndex int64) error {
+	deleted, err := s.stakingRepo.DeleteValidator(ctx, userID, validatorIndex)
+	if err != nil {
+		logger.Error("Failed to remove staking validator", "error", err, "userID", userID, "validatorIndex", validatorIndex)
+		return errors.Internal("Failed to remove validator")
+	}
+	if !deleted {
+		return errors.NotFound("Validator")
+	}
+	return nil
+}
+
+// getValidatorPositions reads the user's tracked validators from the beacon chain. Validators
+// that can't be read are still listed, marked unavailable, so the list stays complete.
+func (s *StakingService) getValidatorPositions(ctx context.Context, userID uuid.UUID) ([]*models.ValidatorPosition, error) {
+	tracked, err := s.stakingRepo.GetValidators(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*models.ValidatorPosition, len(tracked))
+	byIndex := make(map[int64]*models.ValidatorPosition, len(tracked))
+	indices := make([]int64, len(tracked))
+	for i, validator := range tracked {
+		positions[i] = &models.ValidatorPosition{
+			ValidatorIndex: validator.ValidatorIndex,
+			Label:          validator.Label,
+			Status:         validatorStatusUnavailable,
+		}
+		byIndex[validator.ValidatorIndex] = positions[i]
+		indices[i] = validator.ValidatorIndex
+	}
+
+	for start := 0; start < len(indices); start += external.BeaconchainMaxValidators {
+		end := start + external.BeaconchainMaxValidators
+		if end > len(indices) {
+			end = len(indices)
+		}
+		batch := indices[start:end]
+
+		states, err := s.beaconClient.GetValidators(ctx, batch)
+		if err != nil {
+			logger.Warn("Failed to read validators from the beacon chain", "error", err, "count", len(batch))
+			continue
+		}
+		for _, state := range states {
+			if position, ok := byIndex[state.Index]; ok {
+				position.Pubkey = state.Pubkey
+				position.Status = state.Status
+				position.BalanceETH = float64(state.Balance) / gweiPerETH
+				position.EffectiveBalanceETH = float64(state.EffectiveBalance) / gweiPerETH
+			}
+		}
+
+		performance, err := s.beaconClient.GetValidatorPerformance(ctx, batch)
+		if err != nil {
+			logger.Warn("Failed to read validator rewards", "error", err, "count", len(batch))
+			continue
+		}
+		for _, p := range performance {
+			if position, ok := byIndex[p.Index]; ok {
+				position.AccruedETH = float64(p.PerformanceTotal) / gweiPerETH
+			}
+		}
+	}
+
+	return positions, nil
+}
+
+// rollPrincipal carries a holding's principal forward to its current units
+func rollPrincipal(previous *models.LiquidStakingState, units, rate float64) float64 {
+	if previous == nil || previous.Units <= 0 {
+		return units * rate
+	}
+
+	switch {
+	case units > previous.Units*(1+unitsTolerance):
+		return previous.PrincipalETH + (units-previous.Units)*rate
+	case units < previous.Units*(1-unitsTolerance):
+		return previous.PrincipalETH * units / previous.Units
+	default:
+		return previous.PrincipalETH
+	}
+}
+
+// summarizeStaking totals the positions and values them in USD when a price is known
+func summarizeStaking(result *models.StakingPositions, ethPrice *float64) {
+	result.TotalETH, result.TotalAccruedETH = 0, 0
+	for _, position := range result.LiquidStaking {
+		result.TotalETH += position.UnderlyingETH
+		result.TotalAccruedETH += position.AccruedETH
+	}
+	for _, validator := range result.Validators {
+		result.TotalETH += validator.BalanceETH
+		result.TotalAccruedETH += validator.AccruedETH
+	}
+
+	if ethPrice == nil {
+		return
+	}
+	price := *ethPrice
+	result.ETHPriceUSD = &price
+	for _, position := range result.LiquidStaking {
+		value, accrued := position.UnderlyingETH*price, position.AccruedETH*price
+		position.ValueUSD, position.AccruedUSD = &value, &accrued
+	}
+	for _, validator := range result.Validators {
+		value, accrued := validator.BalanceETH*price, validator.AccruedETH*price
+		validator.ValueUSD, validator.AccruedUSD = &value, &accrued
+	}
+	total := result.TotalETH * price
+	result.TotalValueUSD = &total
+}
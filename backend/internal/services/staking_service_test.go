@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollPrincipal(t *testing.T) {
+	// First seen: the whole holding is principal
+	assert.InDelta(t, 11.0, rollPrincipal(nil, 10, 1.1), 1e-9)
+
+	previous := &models.LiquidStakingState{Units: 10, PrincipalETH: 10.5}
+
+	// Unchanged units keep the principal, so the rate increase is yield
+	assert.InDelta(t, 10.5, rollPrincipal(previous, 10, 1.2), 1e-9)
+
+	// Deposits join at the current rate
+	assert.InDelta(t, 12.9, rollPrincipal(previous, 12, 1.2), 1e-9)
+
+	// Withdrawals take their proportional share of the principal
+	assert.InDelta(t, 5.25, rollPrincipal(previous, 5, 1.2), 1e-9)
+
+	// A fully exited holding restarts from the current value
+	assert.InDelta(t, 2.4, rollPrincipal(&models.LiquidStakingState{}, 2, 1.2), 1e-9)
+}
+
+func TestSummarizeStaking(t *testing.T) {
+	result := &models.StakingPositions{
+		LiquidStaking: []*models.LiquidStakingPosition{
+			{Symbol: "rETH", UnderlyingETH: 11, AccruedETH: 0.5},
+		},
+		Validators: []*models.ValidatorPosition{
+			{ValidatorIndex: 1, BalanceETH: 32.01, AccruedETH: 1.2},
+		},
+	}
+
+	summarizeStaking(result, nil)
+	assert.InDelta(t, 43.01, result.TotalETH, 1e-9)
+	assert.InDelta(t, 1.7, result.TotalAccruedETH, 1e-9)
+	assert.Nil(t, result.TotalValueUSD)
+	assert.Nil(t, result.LiquidStaking[0].ValueUSD)
+
+	price := 2000.0
+	summarizeStaking(result, &price)
+	require.NotNil(t, result.TotalValueUSD)
+	assert.InDelta(t, 86020.0, *result.TotalValueUSD, 1e-6)
+	assert.InDelta(t, 1000.0, *result.LiquidStaking[0].AccruedUSD, 1e-9)
+	assert.InDelta(t, 64020.0, *result.Validators[0].ValueUSD, 1e-6)
+}
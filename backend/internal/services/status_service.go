@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+)
+
+const (
+	// statusCacheTTL is how long a computed status is served; status pages poll it
+	statusCacheTTL = 30 * time.Second
+	// statusOutageFactor is how many times over its limit data or a job must be late
+	// before it's an outage rather than degraded
+	statusOutageFactor = 4
+	// providerHealthWindow is how far back provider calls are counted
+	providerHealthWindow = 15 * time.Minute
+	// providerHealthMinCalls is how many calls a provider needs in the window to be judged
+	providerHealthMinCalls = 5
+	// providerDegradedErrorRate is the share of failed calls that degrades a provider
+	providerDegradedErrorRate = 0.2
+)
+
+// statusJob is a worker job a subsystem depends on, and how long after its last success
+// it's late. Jobs run more often than that; the slack absorbs a missed run or two.
+type statusJob struct {
+	name   string
+	maxAge time.Duration
+}
+
+// statusSubsystem is an area of the service reported on the status page
+type statusSubsystem struct {
+	name string
+	// data is the repos.DataSource* whose age is judged, if any
+	data       string
+	staleAfter time.Duration
+	jobs       []statusJob
+	providers  []string
+}
+
+var statusSubsystems = []statusSubsystem{
+	{
+		name: "prices", data: repos.DataSourcePrices, staleAfter: 30 * time.Minute,
+		jobs:      []statusJob{{"price-refresh", 30 * time.Minute}},
+		providers: []string{"coingecko"},
+	},
+	{
+		name: "pools", data: repos.DataSourcePools, staleAfter: 2 * time.Hour,
+		jobs:      []statusJob{{"price-refresh", 30 * time.Minute}, {"protocol-tvl-sync", 3 * time.Hour}},
+		providers: []string{"defillama"},
+	},
+	{
+		name: "balances", data: repos.DataSourceBalances, staleAfter: 20 * time.Minute,
+		jobs:      []statusJob{{"wallet-valuation", 20 * time.Minute}},
+		providers: []string{"alchemy"},
+	},
+	{
+		name: "alerts",
+		jobs: []statusJob{{"alert-evaluator", 20 * time.Minute}, {"notification-outbox", 10 * time.Minute}},
+	},
+}
+
+// StatusService reports the health of each subsystem from how old its data is, when the
+// worker jobs producing it last succeeded and how often its providers' calls fail.
+// Provider health needs PROVIDER_CALL_LOG; without it providers are unknown.
+type StatusService struct {
+	statusRepo       repos.SystemStatusRepository
+	providerCallRepo repos.ProviderCallRepository
+	clock            clock.Clock
+
+	mu     sync.Mutex
+	cached *models.SystemStatus
+}
+
+func NewStatusService(statusRepo repos.SystemStatusRepository, providerCallRepo repos.ProviderCallRepository) *StatusService {
+	return &StatusService{
+		statusRepo:       statusRepo,
+		providerCallRepo: providerCallRepo,
+		clock:            clock.System,
+	}
+}
+
+// GetStatus returns the current status, computed at most once per statusCacheTTL
+func (s *StatusService) GetStatus(ctx context.Context) (*models.SystemStatus, error) {
+	now := s.clock.Now()
+	s.mu.Lock()
+	cached := s.cached
+	s.mu.Unlock()
+	if cached != nil && now.Sub(cached.CheckedAt) < statusCacheTTL {
+		return cached, nil
+	}
+
+	runs, err := s.statusRepo.GetJobRuns(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	freshness, err := s.statusRepo.GetDataFreshness(ctx)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	health, err := s.providerCallRepo.GetHealth(ctx, now.Add(-providerHealthWindow))
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	providers := make(map[string]models.ProviderHealth, len(health))
+	for _, h := range health {
+		providers[h.Provider] = h
+	}
+
+	status := &models.SystemStatus{
+		Status:     models.SystemStatusUnknown,
+		CheckedAt:  now,
+		Subsystems: make([]models.SubsystemStatus, 0, len(statusSubsystems)),
+	}
+	var affected []string
+	for _, subsystem := range statusSubsystems {
+		result := subsystemStatus(subsystem, now, runs, freshness, providers)
+		status.Subsystems = append(status.Subsystems, result)
+		status.Status = worseStatus(status.Status, result.Status)
+		if result.Status == models.SystemStatusDegraded || result.Status == models.SystemStatusOutage {
+			affected = append(affected, result.Name)
+		}
+	}
+	status.Banner = statusBanner(status.Status, affected)
+
+	s.mu.Lock()
+	s.cached = status
+	s.mu.Unlock()
+	return status, nil
+}
+
+func subsystemStatus(subsystem statusSubsystem, now time.Time, runs map[string]*models.JobRun,
+	freshness map[string]time.Time, providers map[string]models.ProviderHealth) models.SubsystemStatus {
+	result := models.SubsystemStatus{
+		Name:      subsystem.name,
+		Status:    models.SystemStatusUnknown,
+		Jobs:      make([]models.JobStatus, 0, len(subsystem.jobs)),
+		Providers: make([]models.ProviderStatus, 0, len(subsystem.providers)),
+	}
+	var problems []string
+
+	if subsystem.data != "" {
+		if updatedAt, ok := freshness[subsystem.data]; ok {
+			age := now.Sub(updatedAt)
+			seconds := int64(age.Seconds())
+			result.DataUpdatedAt = &updatedAt
+			result.DataAgeSeconds = &seconds
+			dataStatus := lateness(age, subsystem.staleAfter)
+			if dataStatus != models.SystemStatusOperational {
+				problems = append(problems, fmt.Sprintf("data is %s old", age.Round(time.Minute)))
+			}
+			result.Status = worseStatus(result.Status, dataStatus)
+		}
+	}
+
+	for _, job := range subsystem.jobs {
+		jobStatus := models.JobStatus{Name: job.name, Status: models.SystemStatusUnknown}
+		if run, ok := runs[job.name]; ok {
+			jobStatus.LastSuccessAt = run.LastSuccessAt
+			jobStatus.LastRunFailed = run.LastError != nil
+			if run.LastSuccessAt == nil {
+				jobStatus.Status = models.SystemStatusOutage
+			} else {
+				jobStatus.Status = lateness(now.Sub(*run.LastSuccessAt), job.maxAge)
+			}
+			if jobStatus.LastRunFailed {
+				jobStatus.Status = worseStatus(jobStatus.Status, models.SystemStatusDegraded)
+			}
+			if jobStatus.Status != models.SystemStatusOperational {
+				problems = append(problems, job.name+" job is failing or late")
+			} else if run.LastDegraded != nil {
+				// The job runs, but left work undone, such as alerts skipped on stale prices
+				jobStatus.Status = models.SystemStatusDegraded
+				jobStatus.Degraded = run.LastDegraded
+				problems = append(problems, job.name+" job "+*run.LastDegraded)
+			}
+		}
+		result.Jobs = append(result.Jobs, jobStatus)
+		result.Status = worseStatus(result.Status, jobStatus.Status)
+	}
+
+	for _, name := range subsystem.providers {
+		providerStatus := models.ProviderStatus{Name: name, Status: models.SystemStatusUnknown}
+		if h, ok := providers[name]; ok {
+			providerStatus.Calls = h.Calls
+			providerStatus.Failures = h.Failures
+			if h.Calls > 0 {
+				providerStatus.ErrorRate = float64(h.Failures) / float64(h.Calls)
+			}
+			if h.Calls >= providerHealthMinCalls {
+				providerStatus.Status = models.SystemStatusOperational
+				if providerStatus.ErrorRate >= providerDegradedErrorRate {
+					providerStatus.Status = models.SystemStatusDegraded
+					problems = append(problems, name+" is returning errors")
+				}
+			}
+		}
+		result.Providers = append(result.Providers, providerStatus)
+		result.Status = worseStatus(result.Status, providerStatus.Status)
+	}
+
+	if len(problems) > 0 {
+		result.Message = strings.Join(problems, "; ")
+	}
+	return result
+}
+
+// lateness judges an age against its limit
+func lateness(age, limit time.Duration) string {
+	switch {
+	case age > statusOutageFactor*limit:
+		return models.SystemStatusOutage
+	case age > limit:
+		return models.SystemStatusDegraded
+	default:
+		return models.SystemStatusOperational
+	}
+}
+
+// statusRank orders statuses from least to most severe. Unknown ranks lowest so a check
+// without data never hides or outweighs one with it.
+var statusRank = map[string]int{
+	models.SystemStatusUnknown:     0,
+	models.SystemStatusOperational: 1,
+	models.SystemStatusDegraded:    2,
+	models.SystemStatusOutage:      3,
+}
+
+func worseStatus(a, b string) string {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
+}
+
+// statusBanner describes a degradation for the frontend's banner, or nil when there's
+// nothing to show. Its ID changes with the affected subsystems so a dismissed banner
+// comes back when something else breaks.
+func statusBanner(status string, affected []string) *models.StatusBanner {
+	if len(affected) == 0 {
+		return nil
+	}
+	banner := &models.StatusBanner{
+		ID:          "status-" + status + "-" + strings.Join(affected, "-"),
+		Title:       "Degraded performance",
+		Message:     "Some data may be delayed: " + strings.Join(affected, ", ") + ".",
+		Type:        "warning",
+		Dismissible: true,
+	}
+	if status == models.SystemStatusOutage {
+		banner.Title = "Service disruption"
+		banner.Message = "Some data is out of date: " + strings.Join(affected, ", ") + "."
+		banner.Type = "error"
+	}
+	return banner
+}
\ No newline at end of file
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusRepoStub struct {
+	repos.SystemStatusRepository
+	runs      map[string]*models.JobRun
+	freshness map[string]time.Time
+	reads     int
+}
+
+func (s *statusRepoStub) GetJobRuns(context.Context) (map[string]*models.JobRun, error) {
+	s.reads++
+	return s.runs, nil
+}
+
+func (s *statusRepoStub) GetDataFreshness(context.Context) (map[string]time.Time, error) {
+	return s.freshness, nil
+}
+
+type providerHealthStub struct {
+	repos.ProviderCallRepository
+	health []models.ProviderHealth
+}
+
+func (p *providerHealthStub) GetHealth(context.Context, time.Time) ([]models.ProviderHealth, error) {
+	return p.health, nil
+}
+
+func TestStatusService(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	ago := func(d time.Duration) *time.Time {
+		at := clk.Now().Add(-d)
+		return &at
+	}
+	failure := "rpc timeout"
+
+	statusRepo := &statusRepoStub{
+		runs: map[string]*models.JobRun{
+			"price-refresh":       {JobName: "price-refresh", LastSuccessAt: ago(5 * time.Minute)},
+			"protocol-tvl-sync":   {JobName: "protocol-tvl-sync", LastSuccessAt: ago(time.Hour)},
+			"wallet-valuation":    {JobName: "wallet-valuation", LastSuccessAt: ago(2 * time.Hour), LastError: &failure},
+			"alert-evaluator":     {JobName: "alert-evaluator", LastSuccessAt: ago(time.Minute)},
+			"notification-outbox": {JobName: "notification-outbox", LastSuccessAt: ago(time.Minute)},
+		},
+		freshness: map[string]time.Time{
+			repos.DataSourcePrices:   *ago(5 * time.Minute),
+			repos.DataSourcePools:    *ago(3 * time.Hour),
+			repos.DataSourceBalances: *ago(2 * time.Hour),
+		},
+	}
+	providers := &providerHealthStub{health: []models.ProviderHealth{
+		{Provider: "coingecko", Calls: 40, Failures: 1},
+		{Provider: "defillama", Calls: 10, Failures: 5},
+		// Too few calls to judge
+		{Provider: "alchemy", Calls: 2, Failures: 2},
+	}}
+	service := NewStatusService(statusRepo, providers)
+	service.clock = clk
+
+	status, err := service.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, models.SystemStatusOutage, status.Status)
+	require.Len(t, status.Subsystems, 4)
+
+	prices := status.Subsystems[0]
+	assert.Equal(t, "prices", prices.Name)
+	assert.Equal(t, models.SystemStatusOperational, prices.Status)
+	assert.Equal(t, int64(300), *prices.DataAgeSeconds)
+	assert.InDelta(t, 0.025, prices.Providers[0].ErrorRate, 1e-9)
+
+	// Stale data and a failing provider degrade the pools
+	pools := status.Subsystems[1]
+	assert.Equal(t, models.SystemStatusDegraded, pools.Status)
+	assert.Equal(t, models.SystemStatusDegraded, pools.Providers[0].Status)
+	assert.Contains(t, pools.Message, "defillama is returning errors")
+
+	// Balances over four times their limit are an outage; the provider isn't judged
+	balances := status.Subsystems[2]
+	assert.Equal(t, models.SystemStatusOutage, balances.Status)
+	assert.Equal(t, models.SystemStatusOutage, balances.Jobs[0].Status)
+	assert.True(t, balances.Jobs[0].LastRunFailed)
+	assert.Equal(t, models.SystemStatusUnknown, balances.Providers[0].Status)
+
+	alerts := status.Subsystems[3]
+	assert.Equal(t, models.SystemStatusOperational, alerts.Status)
+	assert.Nil(t, alerts.DataUpdatedAt)
+
+	require.NotNil(t, status.Banner)
+	assert.Equal(t, "error", status.Banner.Type)
+	assert.Equal(t, "status-outage-pools-balances", status.Banner.ID)
+
+	// The status is cached briefly
+	_, err = service.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, statusRepo.reads)
+
+	// Once everything catches up there's no banner
+	clk.Advance(time.Minute)
+	statusRepo.runs["wallet-valuation"] = &models.JobRun{JobName: "wallet-valuation", LastSuccessAt: ago(0)}
+	statusRepo.freshness[repos.DataSourcePools] = clk.Now()
+	statusRepo.freshness[repos.DataSourceBalances] = clk.Now()
+	providers.health = nil
+	status, err = service.GetStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, statusRepo.reads)
+	assert.Equal(t, models.SystemStatusOperational, status.Status)
+	assert.Nil(t, status.Banner)
+}
+
+func TestStatusServiceWithoutData(t *testing.T) {
+	service := NewStatusService(&statusRepoStub{runs: map[string]*models.JobRun{}, freshness: map[string]time.Time{}}, &providerHealthStub{})
+	status, err := service.GetStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.SystemStatusUnknown, status.Status)
+	assert.Nil(t, status.Banner)
+}
+
+func TestStatusServiceDegradedJob(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Minute)
+	skipped := "skipped 12 alerts on data older than 30m0s"
+	statusRepo := &statusRepoStub{
+		runs: map[string]*models.JobRun{
+			"alert-evaluator":     {JobName: "alert-evaluator", LastSuccessAt: &recent, LastDegraded: &skipped},
+			"notification-outbox": {JobName: "notification-outbox", LastSuccessAt: &recent},
+		},
+		freshness: map[string]time.Time{},
+	}
+	service := NewStatusService(statusRepo, &providerHealthStub{})
+	service.clock = clock.NewFake(now)
+
+	status, err := service.GetStatus(context.Background())
+	require.NoError(t, err)
+	alerts := status.Subsystems[3]
+	assert.Equal(t, "alerts", alerts.Name)
+	assert.Equal(t, models.SystemStatusDegraded, alerts.Status, "alerts skipped on stale prices degrade the alerts")
+	assert.Equal(t, models.SystemStatusDegraded, alerts.Jobs[0].Status)
+	assert.False(t, alerts.Jobs[0].LastRunFailed)
+	require.NotNil(t, alerts.Jobs[0].Degraded)
+	assert.Equal(t, "alert-evaluator job "+skipped, alerts.Message)
+	require.NotNil(t, status.Banner)
+	assert.Equal(t, "status-degraded-alerts", status.Banner.ID)
+}
\ No newline at end of file
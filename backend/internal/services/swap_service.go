@@ -9,20 +9,34 @@ import (
 
 	"github.com/defi-dashboard/backend/internal/clients"
 	"github.com/defi-dashboard/backend/internal/clients/swap"
+	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 )
 
 type SwapService struct {
 	zeroXClient   clients.SwapClient
 	oneInchClient clients.SwapClient
-	cache         clients.Cache
+	quotes        *clients.QuoteCache
+	policies      ProviderPolicySource
+	prices        QuoteTokenPrices
+	// shared lets concurrent identical requests of a user share one GetQuotes
+	shared singleflight.Group
 }
 
 func NewSwapService(zeroXConfig, oneInchConfig clients.ClientConfig) *SwapService {
 	return &SwapService{
 		zeroXClient:   swap.NewZeroXClient(zeroXConfig),
 		oneInchClient: swap.NewOneInchClient(oneInchConfig),
-		cache:         clients.NewMemoryCache(),
+		quotes:        clients.NewQuoteCache(clients.DefaultQuoteStaleTTL, clients.DefaultQuoteNegativeTTL),
+	}
+}
+
+// SetZeroXAPIKey rotates the 0x API key in place
+func (s *SwapService) SetZeroXAPIKey(key string) {
+	if rotator, ok := s.zeroXClient.(clients.KeyRotator); ok {
+		rotator.SetAPIKey(key)
 	}
 }
 
@@ -51,6 +65,8 @@ type SwapRoute struct {
 	Dex          string   `json:"dex"`
 	Calldata     string   `json:"calldata"`
 	Value        string   `json:"value"`
+	// Cost is the route's fees priced the same way for every provider
+	Cost *QuoteCost `json:"cost,omitempty"`
 }
 
 type SwapFees struct {
@@ -59,97 +75,112 @@ type SwapFees struct {
 	Total       string `json:"total"`
 }
 
-func (s *SwapService) GetQuotes(ctx context.Context, req SwapQuoteRequest) ([]SwapRoute, error) {
-	// Convert request to unified format
-	quoteReq := clients.QuoteRequest{
-		FromChainID: strconv.Itoa(req.ChainID),
-		FromToken:   req.FromToken,
-		ToToken:     req.ToToken,
-		Amount:      req.FromAmount,
-		UserAddress: req.UserAddress,
-		Slippage:    req.Slippage,
+// swapProvider is a swap aggregator and how long its quotes are fresh
+type swapProvider struct {
+	name   string
+	client clients.SwapClient
+	ttl    time.Duration
+}
+
+func (s *SwapService) providers() []swapProvider {
+	return []swapProvider{
+		{name: models.ProviderZeroX, client: s.zeroXClient, ttl: 30 * time.Second},
+		{name: models.ProviderOneInch, client: s.oneInchClient, ttl: 60 * time.Second},
 	}
+}
 
-	// Generate cache keys
-	zeroXCacheKey := clients.CacheKey{
-		Provider:    "0x",
-		FromChain:   quoteReq.FromChainID,
-		FromToken:   quoteReq.FromToken,
-		ToToken:     quoteReq.ToToken,
-		Amount:      quoteReq.Amount,
-		UserAddress: quoteReq.UserAddress,
-	}.String()
-
-	oneInchCacheKey := clients.CacheKey{
-		Provider:    "1inch",
-		FromChain:   quoteReq.FromChainID,
-		FromToken:   quoteReq.FromToken,
-		ToToken:     quoteReq.ToToken,
-		Amount:      quoteReq.Amount,
-		UserAddress: quoteReq.UserAddress,
-	}.String()
-
-	var routes []SwapRoute
+// SetProviderPolicies makes quote requests follow the policies; nil lifts them
+func (s *SwapService) SetProviderPolicies(policies ProviderPolicySource) {
+	s.policies = policies
+}
+
+// SetTokenPrices prices the fees quotes leave unpriced; without prices only the USD
+// amounts providers give are counted
+func (s *SwapService) SetTokenPrices(prices QuoteTokenPrices) {
+	s.prices = prices
+}
+
+func (s *SwapService) limits(provider string, chainID int) ProviderLimits {
+	if s.policies == nil {
+		return defaultProviderLimits
+	}
+	return s.policies.Limits(provider, chainID)
+}
+
+// GetQuotes asks every enabled provider for a quote and returns them best first, by
+// output amount weighted by provider policy
+func (s *SwapService) GetQuotes(ctx context.Context, req SwapQuoteRequest) ([]SwapRoute, error) {
+	var ranked []rankedRoute[SwapRoute]
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	// Fetch 0x quote
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-
-		// Check cache first
-		if cachedQuote, found := s.cache.Get(zeroXCacheKey); found {
-			mu.Lock()
-			routes = append(routes, s.convertQuoteToSwapRoute(*cachedQuote, req.GasPrice))
-			mu.Unlock()
-			return
+	enabled := 0
+	for _, provider := range s.providers() {
+		limits := s.limits(provider.name, req.ChainID)
+		if limits.Disabled {
+			continue
 		}
+		enabled++
 
-		// Fetch from API
-		quote, err := s.zeroXClient.GetQuote(ctx, quoteReq)
-		if err == nil {
-			// Cache the quote
-			s.cache.Set(zeroXCacheKey, quote, 30*time.Second)
-			
-			mu.Lock()
-			routes = append(routes, s.convertQuoteToSwapRoute(*quote, req.GasPrice))
-			mu.Unlock()
+		// Convert request to unified format
+		quoteReq := clients.QuoteRequest{
+			FromChainID: strconv.Itoa(req.ChainID),
+			FromToken:   req.FromToken,
+			ToToken:     req.ToToken,
+			Amount:      req.FromAmount,
+			UserAddress: req.UserAddress,
+			Slippage:    cappedSlippage(req.Slippage, limits),
 		}
-	}()
+		cacheKey := clients.CacheKey{
+			Provider:    provider.name,
+			FromChain:   quoteReq.FromChainID,
+			FromToken:   quoteReq.FromToken,
+			ToToken:     quoteReq.ToToken,
+			Amount:      quoteReq.Amount,
+			UserAddress: quoteReq.UserAddress,
+		}.String()
 
-	// Fetch 1inch quote
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-		// Check cache first
-		if cachedQuote, found := s.cache.Get(oneInchCacheKey); found {
-			mu.Lock()
-			routes = append(routes, s.convertQuoteToSwapRoute(*cachedQuote, req.GasPrice))
-			mu.Unlock()
-			return
-		}
+			// Served from cache where possible; expired quotes are refreshed by one call
+			quote, err := s.quotes.Get(ctx, cacheKey, provider.ttl, func(ctx context.Context) (*clients.Quote, error) {
+				return provider.client.GetQuote(ctx, quoteReq)
+			})
+			if err != nil {
+				return
+			}
 
-		// Fetch from API
-		quote, err := s.oneInchClient.GetQuote(ctx, quoteReq)
-		if err == nil {
-			// Cache the quote
-			s.cache.Set(oneInchCacheKey, quote, 60*time.Second)
-			
+			route := s.convertQuoteToSwapRoute(*quote, req.GasPrice)
+			route.Cost = quoteCost(ctx, s.prices, *quote, req.GasPrice)
+			if !withinFeeCap(feeCapTotal(route.Fees.Total, route.Cost), limits) {
+				return
+			}
 			mu.Lock()
-			routes = append(routes, s.convertQuoteToSwapRoute(*quote, req.GasPrice))
+			ranked = append(ranked, rankedRoute[SwapRoute]{route: route, score: routeScore(route.ToAmount, limits.Weight)})
 			mu.Unlock()
-		}
-	}()
+		}()
+	}
 
+	if enabled == 0 {
+		return nil, errQuotingDisabled("Swap")
+	}
 	wg.Wait()
 
-	if len(routes) == 0 {
+	if len(ranked) == 0 {
 		return nil, errors.BadRequest("No swap quotes found")
 	}
 
-	return routes, nil
+	return sortRanked(ranked), nil
+}
+
+// GetUserQuotes is GetQuotes for a user's request. While the same user's identical
+// request is being quoted, it waits for that answer instead of asking the providers again.
+func (s *SwapService) GetUserQuotes(ctx context.Context, userID uuid.UUID, req SwapQuoteRequest) ([]SwapRoute, error) {
+	return shareQuotes(ctx, &s.shared, userID, swapQuoteKey(req), func(ctx context.Context) ([]SwapRoute, error) {
+		return s.GetQuotes(ctx, req)
+	})
 }
 
 // convertQuoteToSwapRoute converts a unified quote to the legacy SwapRoute format
@@ -0,0 +1,689 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxEscalationSteps caps how many steps a policy can have
+	maxEscalationSteps = 10
+	// maxEscalationDelayMinutes is the longest a step can wait after the one before
+	maxEscalationDelayMinutes = 24 * 60
+	// maxOnCallShift is the longest a single on-call shift can run
+	maxOnCallShift = 31 * 24 * time.Hour
+	// defaultOnCallDays is how far ahead shifts are listed unless asked otherwise
+	defaultOnCallDays = 14
+	// teamEscalationLimit caps how many escalations are listed
+	teamEscalationLimit = 100
+	// teamActionTTL is how long a change waits for another owner's approval
+	teamActionTTL = 48 * time.Hour
+	// teamActionLimit caps how many pending actions are listed
+	teamActionLimit = 100
+)
+
+// escalationSharedChannels post to the escalated alert's own destinations
+var escalationSharedChannels = map[string]bool{
+	models.NotificationChannelDiscord:  true,
+	models.NotificationChannelTelegram: true,
+	models.NotificationChannelWebhook:  true,
+}
+
+// escalationMemberChannels go to one team member, named or on call
+var escalationMemberChannels = map[string]bool{
+	models.NotificationChannelEmail: true,
+	models.NotificationChannelInApp: true,
+}
+
+// TeamService manages teams, their escalation policies and on-call schedule, and the
+// escalations their members acknowledge. Only owners change a team; any member can
+// read it and acknowledge its escalations. In teams with several owners, removing a
+// member and changing or deleting a policy wait for a second owner's approval.
+type TeamService struct {
+	teamRepo       repos.TeamRepository
+	actionRepo     repos.TeamActionRepository
+	escalationRepo repos.EscalationRepository
+	alertRepo      repos.AlertRepository
+	userRepo       repos.UserRepository
+	clock          clock.Clock
+}
+
+func NewTeamService(teamRepo repos.TeamRepository, actionRepo repos.TeamActionRepository, escalationRepo repos.EscalationRepository, alertRepo repos.AlertRepository, userRepo repos.UserRepository) *TeamService {
+	return &TeamService{
+		teamRepo:       teamRepo,
+		actionRepo:     actionRepo,
+		escalationRepo: escalationRepo,
+		alertRepo:      alertRepo,
+		userRepo:       userRepo,
+		clock:          clock.System,
+	}
+}
+
+// CreateTeam creates a team owned by the user
+func (s *TeamService) CreateTeam(ctx context.Context, userID uuid.UUID, req *models.CreateTeamRequest) (*models.Team, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, errors.BadRequest("Name must be between 1 and 100 characters")
+	}
+
+	team := &models.Team{Name: name, CreatedBy: userID}
+	if err := s.teamRepo.Create(ctx, team); err != nil {
+		logger.Error("Failed to create team", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to create team")
+	}
+	return team, nil
+}
+
+// GetTeams returns the teams the user is a member of
+func (s *TeamService) GetTeams(ctx context.Context, userID uuid.UUID) ([]*models.Team, error) {
+	teams, err := s.teamRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return teams, nil
+}
+
+// requireRole checks the user is a member of the team, and an owner when ownerOnly.
+// Non-members get not found so teams' existence isn't leaked.
+func (s *TeamService) requireRole(ctx context.Context, teamID, userID uuid.UUID, ownerOnly bool) error {
+	role, err := s.teamRepo.GetRole(ctx, teamID, userID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if role == "" {
+		return errors.NotFound("Team")
+	}
+	if ownerOnly && role != models.TeamRoleOwner {
+		return errors.Forbidden("Only team owners can do this")
+	}
+	return nil
+}
+
+func (s *TeamService) GetMembers(ctx context.Context, userID, teamID uuid.UUID) ([]*models.TeamMember, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+	members, err := s.teamRepo.GetMembers(ctx, teamID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return members, nil
+}
+
+// AddMember adds the user with the wallet address to the team, as a member unless
+// asked otherwise
+func (s *TeamService) AddMember(ctx context.Context, userID, teamID uuid.UUID, req *models.AddTeamMemberRequest) (*models.TeamMember, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, err
+	}
+	role := req.Role
+	if role == "" {
+		role = models.TeamRoleMember
+	}
+	if role != models.TeamRoleOwner && role != models.TeamRoleMember {
+		return nil, errors.BadRequest("Role must be owner or member")
+	}
+
+	user, err := s.userRepo.GetByAddress(ctx, req.Address)
+	if err != nil {
+		return nil, repoError(err, "User")
+	}
+
+	member := &models.TeamMember{TeamID: teamID, UserID: user.ID, Address: user.Address, Role: role}
+	if err := s.teamRepo.AddMember(ctx, member); err != nil {
+		if err == repos.ErrTeamMemberExists {
+			return nil, errors.Conflict("User is already a team member")
+		}
+		logger.Error("Failed to add team member", "error", err, "teamID", teamID)
+		return nil, errors.Internal("Failed to add team member")
+	}
+	return member, nil
+}
+
+// RemoveMember removes a member from the team. Owners can remove anyone and members
+// themselves, but the last owner can't leave. When another owner could approve it,
+// removing someone else is queued instead and the pending action returned.
+func (s *TeamService) RemoveMember(ctx context.Context, userID, teamID, memberID uuid.UUID) (*models.TeamPendingAction, error) {
+	if err := s.requireRole(ctx, teamID, userID, memberID != userID); err != nil {
+		return nil, err
+	}
+	owners, err := s.checkRemovable(ctx, teamID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if memberID != userID {
+		pending, err := s.requestApproval(ctx, teamID, userID, models.TeamActionRemoveMember, memberID, nil, owners)
+		if err != nil || pending != nil {
+			return pending, err
+		}
+	}
+	return nil, s.removeMember(ctx, teamID, memberID)
+}
+
+// checkRemovable checks the user is a member who can leave the team, returning how
+// many owners it has
+func (s *TeamService) checkRemovable(ctx context.Context, teamID, memberID uuid.UUID) (int, error) {
+	members, err := s.teamRepo.GetMembers(ctx, teamID)
+	if err != nil {
+		return 0, errors.DatabaseError(err)
+	}
+	owners, found, removingOwner := 0, false, false
+	for _, m := range members {
+		found = found || m.UserID == memberID
+		if m.Role == models.TeamRoleOwner {
+			owners++
+			removingOwner = removingOwner || m.UserID == memberID
+		}
+	}
+	if !found {
+		return 0, errors.NotFound("Team member")
+	}
+	if removingOwner && owners == 1 {
+		return 0, errors.BadRequest("A team must keep at least one owner")
+	}
+	return owners, nil
+}
+
+func (s *TeamService) removeMember(ctx context.Context, teamID, memberID uuid.UUID) error {
+	removed, err := s.teamRepo.RemoveMember(ctx, teamID, memberID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !removed {
+		return errors.NotFound("Team member")
+	}
+	return nil
+}
+
+func (s *TeamService) GetPolicies(ctx context.Context, userID, teamID uuid.UUID) ([]*models.EscalationPolicy, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+	policies, err := s.teamRepo.GetPolicies(ctx, teamID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return policies, nil
+}
+
+func (s *TeamService) CreatePolicy(ctx context.Context, userID, teamID uuid.UUID, req *models.EscalationPolicyRequest) (*models.EscalationPolicy, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, err
+	}
+	if err := s.validatePolicy(ctx, teamID, req); err != nil {
+		return nil, err
+	}
+
+	policy := &models.EscalationPolicy{TeamID: teamID, Name: strings.TrimSpace(req.Name), Steps: req.Steps}
+	if err := s.teamRepo.CreatePolicy(ctx, policy); err != nil {
+		logger.Error("Failed to create escalation policy", "error", err, "teamID", teamID)
+		return nil, errors.Internal("Failed to create escalation policy")
+	}
+	return policy, nil
+}
+
+// UpdatePolicy replaces a policy's name and steps, or queues the change for another
+// owner's approval and returns the pending action
+func (s *TeamService) UpdatePolicy(ctx context.Context, userID, teamID, policyID uuid.UUID, req *models.EscalationPolicyRequest) (*models.EscalationPolicy, *models.TeamPendingAction, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, nil, err
+	}
+	existing, err := s.getPolicy(ctx, teamID, policyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.validatePolicy(ctx, teamID, req); err != nil {
+		return nil, nil, err
+	}
+
+	owners, err := s.countOwners(ctx, teamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending, err := s.requestApproval(ctx, teamID, userID, models.TeamActionUpdateEscalationPolicy, policyID, req, owners)
+	if err != nil || pending != nil {
+		return nil, pending, err
+	}
+	if err := s.updatePolicy(ctx, existing, req); err != nil {
+		return nil, nil, err
+	}
+	return existing, nil, nil
+}
+
+func (s *TeamService) updatePolicy(ctx context.Context, policy *models.EscalationPolicy, req *models.EscalationPolicyRequest) error {
+	policy.Name, policy.Steps = strings.TrimSpace(req.Name), req.Steps
+	if err := s.teamRepo.UpdatePolicy(ctx, policy); err != nil {
+		logger.Error("Failed to update escalation policy", "error", err, "policyID", policy.ID)
+		return errors.Internal("Failed to update escalation policy")
+	}
+	return nil
+}
+
+// DeletePolicy deletes a policy, or queues the deletion for another owner's approval
+// and returns the pending action. Alerts escalating through a deleted policy go back
+// to notifying only their owner.
+func (s *TeamService) DeletePolicy(ctx context.Context, userID, teamID, policyID uuid.UUID) (*models.TeamPendingAction, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, err
+	}
+	if _, err := s.getPolicy(ctx, teamID, policyID); err != nil {
+		return nil, err
+	}
+
+	owners, err := s.countOwners(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.requestApproval(ctx, teamID, userID, models.TeamActionDeleteEscalationPolicy, policyID, nil, owners)
+	if err != nil || pending != nil {
+		return pending, err
+	}
+	return nil, s.deletePolicy(ctx, teamID, policyID)
+}
+
+func (s *TeamService) deletePolicy(ctx context.Context, teamID, policyID uuid.UUID) error {
+	deleted, err := s.teamRepo.DeletePolicy(ctx, teamID, policyID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Escalation policy")
+	}
+	return nil
+}
+
+// getPolicy returns the team's policy, or not found when the team has no such policy
+func (s *TeamService) getPolicy(ctx context.Context, teamID, policyID uuid.UUID) (*models.EscalationPolicy, error) {
+	policy, err := s.teamRepo.GetPolicy(ctx, policyID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if policy == nil || policy.TeamID != teamID {
+		return nil, errors.NotFound("Escalation policy")
+	}
+	return policy, nil
+}
+
+// validatePolicy checks a policy's steps. Named members must be on the team.
+func (s *TeamService) validatePolicy(ctx context.Context, teamID uuid.UUID, req *models.EscalationPolicyRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return errors.BadRequest("Name must be between 1 and 100 characters")
+	}
+	if err := validateEscalationSteps(req.Steps); err != nil {
+		return err
+	}
+
+	for i, step := range req.Steps {
+		if step.UserID == nil {
+			continue
+		}
+		role, err := s.teamRepo.GetRole(ctx, teamID, *step.UserID)
+		if err != nil {
+			return errors.DatabaseError(err)
+		}
+		if role == "" {
+			return errors.BadRequest(fmt.Sprintf("Step %d: user is not a team member", i+1))
+		}
+	}
+	return nil
+}
+
+// validateEscalationSteps checks the shape of a policy's steps
+func validateEscalationSteps(steps []models.EscalationStep) error {
+	if len(steps) == 0 || len(steps) > maxEscalationSteps {
+		return errors.BadRequest(fmt.Sprintf("A policy must have between 1 and %d steps", maxEscalationSteps))
+	}
+
+	for i, step := range steps {
+		if step.DelayMinutes < 0 || step.DelayMinutes > maxEscalationDelayMinutes {
+			return errors.BadRequest(fmt.Sprintf("Step %d: delay_minutes must be between 0 and %d", i+1, maxEscalationDelayMinutes))
+		}
+		switch {
+		case escalationSharedChannels[step.Channel]:
+			if step.UserID != nil || step.OnCall {
+				return errors.BadRequest(fmt.Sprintf("Step %d: %s steps post to the alert's own destination and take no recipient", i+1, step.Channel))
+			}
+		case escalationMemberChannels[step.Channel]:
+			if (step.UserID != nil) == step.OnCall {
+				return errors.BadRequest(fmt.Sprintf("Step %d: %s steps need either user_id or on_call", i+1, step.Channel))
+			}
+		default:
+			return errors.BadRequest(fmt.Sprintf("Step %d: channel must be discord, telegram, webhook, email or in_app", i+1))
+		}
+	}
+	return nil
+}
+
+// GetShifts returns the team's shifts overlapping [from, to). Zero times default to
+// now and the default number of days after from.
+func (s *TeamService) GetShifts(ctx context.Context, userID, teamID uuid.UUID, from, to time.Time) ([]*models.OnCallShift, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+	if from.IsZero() {
+		from = s.clock.Now()
+	}
+	if to.IsZero() {
+		to = from.AddDate(0, 0, defaultOnCallDays)
+	}
+	if !to.After(from) {
+		return nil, errors.BadRequest("to must be after from")
+	}
+
+	shifts, err := s.teamRepo.GetShifts(ctx, teamID, from, to)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return shifts, nil
+}
+
+// GetOnCall returns the shift on call right now, or nil when nobody is
+func (s *TeamService) GetOnCall(ctx context.Context, userID, teamID uuid.UUID) (*models.OnCallShift, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	shifts, err := s.teamRepo.GetShifts(ctx, teamID, now, now.Add(time.Second))
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return currentOnCall(shifts, now), nil
+}
+
+func (s *TeamService) CreateShift(ctx context.Context, userID, teamID uuid.UUID, req *models.CreateOnCallShiftRequest) (*models.OnCallShift, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, err
+	}
+	if req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt) {
+		return nil, errors.BadRequest("ends_at must be after starts_at")
+	}
+	if req.EndsAt.Sub(req.StartsAt) > maxOnCallShift {
+		return nil, errors.BadRequest("A shift can last at most 31 days")
+	}
+	role, err := s.teamRepo.GetRole(ctx, teamID, req.UserID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if role == "" {
+		return nil, errors.BadRequest("User is not a team member")
+	}
+
+	shift := &models.OnCallShift{TeamID: teamID, UserID: req.UserID, StartsAt: req.StartsAt, EndsAt: req.EndsAt}
+	if err := s.teamRepo.CreateShift(ctx, shift); err != nil {
+		logger.Error("Failed to create on-call shift", "error", err, "teamID", teamID)
+		return nil, errors.Internal("Failed to create on-call shift")
+	}
+	return shift, nil
+}
+
+func (s *TeamService) DeleteShift(ctx context.Context, userID, teamID, shiftID uuid.UUID) error {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return err
+	}
+	deleted, err := s.teamRepo.DeleteShift(ctx, teamID, shiftID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("On-call shift")
+	}
+	return nil
+}
+
+// GetEscalations returns the team's latest escalations, optionally of one status
+func (s *TeamService) GetEscalations(ctx context.Context, userID, teamID uuid.UUID, status string) ([]*models.AlertEscalation, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+	var statusFilter *string
+	if status != "" {
+		if status != models.EscalationStatusOpen && status != models.EscalationStatusAcknowledged && status != models.EscalationStatusExhausted {
+			return nil, errors.BadRequest("Status must be open, acknowledged or exhausted")
+		}
+		statusFilter = &status
+	}
+
+	escalations, err := s.escalationRepo.GetByTeam(ctx, teamID, statusFilter, teamEscalationLimit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return escalations, nil
+}
+
+// Acknowledge stops an escalation going any further. Any member of the team can
+// acknowledge it, including after its steps have run out.
+func (s *TeamService) Acknowledge(ctx context.Context, userID, teamID, escalationID uuid.UUID) (*models.AlertEscalation, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+
+	acknowledged, err := s.escalationRepo.Acknowledge(ctx, teamID, escalationID, userID, s.clock.Now())
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	escalation, err := s.escalationRepo.GetByID(ctx, escalationID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if escalation == nil || escalation.TeamID != teamID {
+		return nil, errors.NotFound("Escalation")
+	}
+	if !acknowledged {
+		return nil, errors.Conflict("Escalation is already acknowledged")
+	}
+	return escalation, nil
+}
+
+// SetAlertPolicy routes the user's alert through an escalation policy of a team they're
+// a member of, or back to notifying only them when req.PolicyID is nil
+func (s *TeamService) SetAlertPolicy(ctx context.Context, userID, alertID uuid.UUID, req *models.SetEscalationPolicyRequest) (*models.Alert, error) {
+	alert, err := s.alertRepo.GetByID(ctx, alertID)
+	if err != nil || alert.UserID != userID {
+		return nil, errors.NotFound("Alert")
+	}
+
+	if req.PolicyID != nil {
+		policy, err := s.teamRepo.GetPolicy(ctx, *req.PolicyID)
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		if policy == nil {
+			return nil, errors.NotFound("Escalation policy")
+		}
+		role, err := s.teamRepo.GetRole(ctx, policy.TeamID, userID)
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		if role == "" {
+			return nil, errors.NotFound("Escalation policy")
+		}
+	}
+
+	if err := s.escalationRepo.SetAlertPolicy(ctx, alertID, req.PolicyID); err != nil {
+		logger.Error("Failed to set alert escalation policy", "error", err, "alertID", alertID)
+		return nil, errors.Internal("Failed to set escalation policy")
+	}
+	alert.EscalationPolicyID = req.PolicyID
+	return alert, nil
+}
+
+func (s *TeamService) countOwners(ctx context.Context, teamID uuid.UUID) (int, error) {
+	members, err := s.teamRepo.GetMembers(ctx, teamID)
+	if err != nil {
+		return 0, errors.DatabaseError(err)
+	}
+	owners := 0
+	for _, m := range members {
+		if m.Role == models.TeamRoleOwner {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+// requestApproval queues a change for another owner's approval. It returns nil when
+// the team has no other owner to approve it, and the change should go ahead.
+func (s *TeamService) requestApproval(ctx context.Context, teamID, userID uuid.UUID, action string, targetID uuid.UUID, payload interface{}, owners int) (*models.TeamPendingAction, error) {
+	if owners < 2 {
+		return nil, nil
+	}
+
+	pending := &models.TeamPendingAction{
+		TeamID:      teamID,
+		Action:      action,
+		TargetID:    targetID,
+		RequestedBy: userID,
+		ExpiresAt:   s.clock.Now().Add(teamActionTTL),
+	}
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, errors.Internal("Failed to encode change")
+		}
+		pending.Payload = raw
+	}
+	if err := s.actionRepo.Create(ctx, pending); err != nil {
+		if err == repos.ErrTeamActionPending {
+			return nil, errors.Conflict("This change is already awaiting approval")
+		}
+		logger.Error("Failed to queue team action", "error", err, "teamID", teamID, "action", action)
+		return nil, errors.Internal("Failed to request approval")
+	}
+	logger.Info("Team change awaiting approval", "teamID", teamID, "action", action, "actionID", pending.ID, "userID", userID)
+	return pending, nil
+}
+
+// GetPendingActions returns the team's latest requested changes, whatever their status
+func (s *TeamService) GetPendingActions(ctx context.Context, userID, teamID uuid.UUID) ([]*models.TeamPendingAction, error) {
+	if err := s.requireRole(ctx, teamID, userID, false); err != nil {
+		return nil, err
+	}
+	actions, err := s.actionRepo.GetByTeam(ctx, teamID, teamActionLimit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return actions, nil
+}
+
+// ApproveAction approves another owner's pending change and applies it. A change
+// that can no longer be applied, such as removing a member who has since left, is
+// marked failed.
+func (s *TeamService) ApproveAction(ctx context.Context, userID, teamID, actionID uuid.UUID) (*models.TeamPendingAction, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, err
+	}
+
+	action, err := s.actionRepo.Approve(ctx, teamID, actionID, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if action == nil {
+		return nil, s.undecidableError(ctx, teamID, actionID, userID)
+	}
+
+	if err := s.applyAction(ctx, action); err != nil {
+		message := err.Error()
+		if appErr, ok := err.(*errors.AppError); ok {
+			message = appErr.Message
+		}
+		if markErr := s.actionRepo.MarkFailed(ctx, action.ID, message); markErr != nil {
+			logger.Error("Failed to mark team action failed", "error", markErr, "actionID", action.ID)
+		}
+		return nil, err
+	}
+	logger.Info("Team change approved", "teamID", teamID, "action", action.Action, "actionID", action.ID, "userID", userID)
+	return action, nil
+}
+
+// RejectAction rejects a pending change. The owner who requested it can reject it to
+// withdraw the request.
+func (s *TeamService) RejectAction(ctx context.Context, userID, teamID, actionID uuid.UUID) (*models.TeamPendingAction, error) {
+	if err := s.requireRole(ctx, teamID, userID, true); err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.actionRepo.Reject(ctx, teamID, actionID, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if !rejected {
+		return nil, s.undecidableError(ctx, teamID, actionID, uuid.Nil)
+	}
+	action, err := s.actionRepo.GetByID(ctx, actionID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return action, nil
+}
+
+// undecidableError explains why an action couldn't be approved or rejected
+func (s *TeamService) undecidableError(ctx context.Context, teamID, actionID, approverID uuid.UUID) error {
+	action, err := s.actionRepo.GetByID(ctx, actionID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if action == nil || action.TeamID != teamID {
+		return errors.NotFound("Pending action")
+	}
+	if action.Status != models.TeamActionStatusPending {
+		return errors.Conflict(fmt.Sprintf("Action is already %s", action.Status))
+	}
+	if action.RequestedBy == approverID {
+		return errors.Forbidden("Another owner must approve this change")
+	}
+	return errors.Conflict("Action can no longer be decided")
+}
+
+// applyAction makes an approved change, checking it still makes sense now
+func (s *TeamService) applyAction(ctx context.Context, action *models.TeamPendingAction) error {
+	switch action.Action {
+	case models.TeamActionRemoveMember:
+		if _, err := s.checkRemovable(ctx, action.TeamID, action.TargetID); err != nil {
+			return err
+		}
+		return s.removeMember(ctx, action.TeamID, action.TargetID)
+	case models.TeamActionUpdateEscalationPolicy:
+		var req models.EscalationPolicyRequest
+		if err := json.Unmarshal(action.Payload, &req); err != nil {
+			return errors.Internal("Failed to decode change")
+		}
+		policy, err := s.getPolicy(ctx, action.TeamID, action.TargetID)
+		if err != nil {
+			return err
+		}
+		if err := s.validatePolicy(ctx, action.TeamID, &req); err != nil {
+			return err
+		}
+		return s.updatePolicy(ctx, policy, &req)
+	case models.TeamActionDeleteEscalationPolicy:
+		return s.deletePolicy(ctx, action.TeamID, action.TargetID)
+	default:
+		return errors.Internal(fmt.Sprintf("Unknown team action %q", action.Action))
+	}
+}
+
+// currentOnCall returns the shift covering at, preferring the one that started last so
+// a short override takes over from the regular rotation
+func currentOnCall(shifts []*models.OnCallShift, at time.Time) *models.OnCallShift {
+	var current *models.OnCallShift
+	for _, shift := range shifts {
+		if at.Before(shift.StartsAt) || !at.Before(shift.EndsAt) {
+			continue
+		}
+		if current == nil || shift.StartsAt.After(current.StartsAt) {
+			current = shift
+		}
+	}
+	return current
+}
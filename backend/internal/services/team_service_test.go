@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type teamRepoFake struct {
+	repos.TeamRepository
+	members  []*models.TeamMember
+	policies map[uuid.UUID]*models.EscalationPolicy
+}
+
+func (r *teamRepoFake) GetRole(_ context.Context, _, userID uuid.UUID) (string, error) {
+	for _, m := range r.members {
+		if m.UserID == userID {
+			return m.Role, nil
+		}
+	}
+	return "", nil
+}
+
+func (r *teamRepoFake) GetMembers(context.Context, uuid.UUID) ([]*models.TeamMember, error) {
+	return r.members, nil
+}
+
+func (r *teamRepoFake) RemoveMember(_ context.Context, _, userID uuid.UUID) (bool, error) {
+	for i, m := range r.members {
+		if m.UserID == userID {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *teamRepoFake) GetPolicy(_ context.Context, id uuid.UUID) (*models.EscalationPolicy, error) {
+	return r.policies[id], nil
+}
+
+func (r *teamRepoFake) UpdatePolicy(_ context.Context, policy *models.EscalationPolicy) error {
+	r.policies[policy.ID] = policy
+	return nil
+}
+
+func (r *teamRepoFake) DeletePolicy(_ context.Context, _, id uuid.UUID) (bool, error) {
+	_, ok := r.policies[id]
+	delete(r.policies, id)
+	return ok, nil
+}
+
+// teamActionsFake keeps actions in memory, expiring them against now
+type teamActionsFake struct {
+	actions map[uuid.UUID]*models.TeamPendingAction
+	now     func() time.Time
+}
+
+func (r *teamActionsFake) status(a *models.TeamPendingAction) string {
+	if a.Status == models.TeamActionStatusPending && !a.ExpiresAt.After(r.now()) {
+		return models.TeamActionStatusExpired
+	}
+	return a.Status
+}
+
+func (r *teamActionsFake) Create(_ context.Context, action *models.TeamPendingAction) error {
+	for _, a := range r.actions {
+		if a.TeamID == action.TeamID && a.Action == action.Action && a.TargetID == action.TargetID &&
+			r.status(a) == models.TeamActionStatusPending {
+			return repos.ErrTeamActionPending
+		}
+	}
+	action.ID, action.Status, action.CreatedAt = uuid.New(), models.TeamActionStatusPending, r.now()
+	saved := *action
+	r.actions[action.ID] = &saved
+	return nil
+}
+
+func (r *teamActionsFake) GetByID(_ context.Context, id uuid.UUID) (*models.TeamPendingAction, error) {
+	a, ok := r.actions[id]
+	if !ok {
+		return nil, nil
+	}
+	got := *a
+	got.Status = r.status(a)
+	return &got, nil
+}
+
+func (r *teamActionsFake) decide(teamID, id, userID uuid.UUID, status string) *models.TeamPendingAction {
+	a, ok := r.actions[id]
+	if !ok || a.TeamID != teamID || r.status(a) != models.TeamActionStatusPending {
+		return nil
+	}
+	if status == models.TeamActionStatusApproved && a.RequestedBy == userID {
+		return nil
+	}
+	now := r.now()
+	a.Status, a.DecidedBy, a.DecidedAt = status, &userID, &now
+	got := *a
+	return &got
+}
+
+func (r *teamActionsFake) Approve(_ context.Context, teamID, id, approverID uuid.UUID) (*models.TeamPendingAction, error) {
+	return r.decide(teamID, id, approverID, models.TeamActionStatusApproved), nil
+}
+
+func (r *teamActionsFake) Reject(_ context.Context, teamID, id, userID uuid.UUID) (bool, error) {
+	return r.decide(teamID, id, userID, models.TeamActionStatusRejected) != nil, nil
+}
+
+func (r *teamActionsFake) MarkFailed(_ context.Context, id uuid.UUID, message string) error {
+	r.actions[id].Status, r.actions[id].Error = models.TeamActionStatusFailed, &message
+	return nil
+}
+
+func (r *teamActionsFake) GetByTeam(context.Context, uuid.UUID, int) ([]*models.TeamPendingAction, error) {
+	var actions []*models.TeamPendingAction
+	for id := range r.actions {
+		a, _ := r.GetByID(context.Background(), id)
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+func TestTeamChangesNeedSecondOwner(t *testing.T) {
+	ctx := context.Background()
+	teamID := uuid.New()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	policyID := uuid.New()
+	teams := &teamRepoFake{
+		members: []*models.TeamMember{
+			{TeamID: teamID, UserID: alice, Role: models.TeamRoleOwner},
+			{TeamID: teamID, UserID: bob, Role: models.TeamRoleOwner},
+			{TeamID: teamID, UserID: carol, Role: models.TeamRoleMember},
+		},
+		policies: map[uuid.UUID]*models.EscalationPolicy{
+			policyID: {ID: policyID, TeamID: teamID, Name: "Primary", Steps: []models.EscalationStep{{Channel: models.NotificationChannelDiscord}}},
+		},
+	}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	actions := &teamActionsFake{actions: map[uuid.UUID]*models.TeamPendingAction{}, now: clk.Now}
+	service := &TeamService{teamRepo: teams, actionRepo: actions, clock: clk}
+	status := func(err error) int {
+		require.Error(t, err)
+		return err.(*errors.AppError).Status
+	}
+
+	// Removing carol waits for another owner
+	pending, err := service.RemoveMember(ctx, alice, teamID, carol)
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, models.TeamActionRemoveMember, pending.Action)
+	assert.Equal(t, now.Add(teamActionTTL), pending.ExpiresAt)
+	assert.Len(t, teams.members, 3)
+
+	_, err = service.RemoveMember(ctx, bob, teamID, carol)
+	assert.Equal(t, 409, status(err), "the same change can't be queued twice")
+	_, err = service.ApproveAction(ctx, alice, teamID, pending.ID)
+	assert.Equal(t, 403, status(err), "requesters can't approve their own change")
+	_, err = service.ApproveAction(ctx, carol, teamID, pending.ID)
+	assert.Equal(t, 403, status(err), "members can't approve")
+
+	approved, err := service.ApproveAction(ctx, bob, teamID, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TeamActionStatusApproved, approved.Status)
+	assert.Equal(t, &bob, approved.DecidedBy)
+	assert.Len(t, teams.members, 2)
+	_, err = service.ApproveAction(ctx, bob, teamID, pending.ID)
+	assert.Equal(t, 409, status(err))
+
+	// Policy changes are validated up front and applied on approval
+	req := &models.EscalationPolicyRequest{Name: "Paging", Steps: []models.EscalationStep{{Channel: models.NotificationChannelWebhook}}}
+	_, pending, err = service.UpdatePolicy(ctx, alice, teamID, policyID, &models.EscalationPolicyRequest{Name: "Bad"})
+	assert.Equal(t, 400, status(err))
+	policy, pending, err := service.UpdatePolicy(ctx, alice, teamID, policyID, req)
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+	require.NotNil(t, pending)
+	assert.Equal(t, "Primary", teams.policies[policyID].Name)
+	_, err = service.ApproveAction(ctx, bob, teamID, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Paging", teams.policies[policyID].Name)
+	assert.Equal(t, req.Steps, teams.policies[policyID].Steps)
+
+	// Requesters can withdraw, and a request lapses after its TTL
+	pending, err = service.DeletePolicy(ctx, alice, teamID, policyID)
+	require.NoError(t, err)
+	rejected, err := service.RejectAction(ctx, alice, teamID, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TeamActionStatusRejected, rejected.Status)
+
+	pending, err = service.DeletePolicy(ctx, alice, teamID, policyID)
+	require.NoError(t, err)
+	clk.Advance(teamActionTTL)
+	_, err = service.ApproveAction(ctx, bob, teamID, pending.ID)
+	assert.Equal(t, 409, status(err))
+	assert.Contains(t, teams.policies, policyID)
+	_, err = service.ApproveAction(ctx, bob, uuid.New(), pending.ID)
+	assert.Equal(t, 404, status(err))
+
+	// A change that no longer applies fails
+	pending, err = service.DeletePolicy(ctx, alice, teamID, policyID)
+	require.NoError(t, err)
+	delete(teams.policies, policyID)
+	_, err = service.ApproveAction(ctx, bob, teamID, pending.ID)
+	assert.Equal(t, 404, status(err))
+	assert.Equal(t, models.TeamActionStatusFailed, actions.actions[pending.ID].Status)
+
+	// With a single owner, changes go ahead at once
+	teams.members = teams.members[:1]
+	teams.members = append(teams.members, &models.TeamMember{TeamID: teamID, UserID: carol, Role: models.TeamRoleMember})
+	pending, err = service.RemoveMember(ctx, alice, teamID, carol)
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+	assert.Len(t, teams.members, 1)
+}
\ No newline at end of file
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/google/uuid"
+)
+
+// smallCapMarketCapUSD is the market cap below which a token is rated as a small cap,
+// whose price a few large holders can move
+const smallCapMarketCapUSD = 50_000_000
+
+// ConcentrationRisk rates a token's holder concentration from the share of its supply
+// its ten largest holders hold. Small caps, and tokens without a known market cap, are
+// rated more strictly. Exchange and bridge wallets count as holders, so large caps
+// commonly have a high share without much risk.
+func ConcentrationRisk(top10SharePct float64, marketCap *float64) string {
+	smallCap := marketCap == nil || *marketCap < smallCapMarketCapUSD
+	switch {
+	case top10SharePct >= 90:
+		return models.ConcentrationRiskHigh
+	case top10SharePct >= 70 && smallCap:
+		return models.ConcentrationRiskHigh
+	case top10SharePct >= 70, top10SharePct >= 40 && smallCap:
+		return models.ConcentrationRiskMedium
+	default:
+		return models.ConcentrationRiskLow
+	}
+}
+
+// TokenHolderService adds holder concentration, refreshed by the worker's token-holders
+// job, to token details
+type TokenHolderService struct {
+	holderRepo repos.TokenHolderRepository
+}
+
+func NewTokenHolderService(holderRepo repos.TokenHolderRepository) *TokenHolderService {
+	return &TokenHolderService{holderRepo: holderRepo}
+}
+
+// Attach sets a token's holder stats and the share of its supply the user's wallets
+// hold. Tokens whose holders weren't fetched are left as they are.
+func (s *TokenHolderService) Attach(ctx context.Context, token *models.Token, userID uuid.UUID) error {
+	stats, err := s.holderRepo.Get(ctx, token.ID)
+	if err != nil || stats == nil {
+		return err
+	}
+	stats.ConcentrationRisk = ConcentrationRisk(stats.Top10SharePct, token.MarketCap)
+	token.Holders = stats
+
+	supply, err := amounts.ParseBaseUnits(stats.TotalSupply)
+	if err != nil || supply.Sign() == 0 {
+		return nil
+	}
+	balance, err := s.holderRepo.GetUserBalance(ctx, userID, token.ID)
+	if err != nil {
+		return err
+	}
+	held, err := amounts.ParseBaseUnits(balance)
+	if err != nil {
+		return nil
+	}
+	pct := amounts.Percent(held, supply)
+	token.UserSupplyPct = &pct
+	return nil
+}
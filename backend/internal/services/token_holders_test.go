@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storedHolders serves one token's holder stats and one user's balance of it
+type storedHolders struct {
+	repos.TokenHolderRepository
+	stats   *models.TokenHolderStats
+	userID  uuid.UUID
+	balance string
+}
+
+func (r *storedHolders) Get(_ context.Context, tokenID uuid.UUID) (*models.TokenHolderStats, error) {
+	if r.stats == nil || r.stats.TokenID != tokenID {
+		return nil, nil
+	}
+	copied := *r.stats
+	return &copied, nil
+}
+
+func (r *storedHolders) GetUserBalance(_ context.Context, userID, _ uuid.UUID) (string, error) {
+	if userID != r.userID {
+		return "0", nil
+	}
+	return r.balance, nil
+}
+
+func TestConcentrationRisk(t *testing.T) {
+	smallCap, largeCap := 2_000_000.0, 5_000_000_000.0
+	tests := []struct {
+		top10     float64
+		marketCap *float64
+		want      string
+	}{
+		{95, &largeCap, models.ConcentrationRiskHigh},
+		{75, &smallCap, models.ConcentrationRiskHigh},
+		{75, nil, models.ConcentrationRiskHigh},
+		{75, &largeCap, models.ConcentrationRiskMedium},
+		{45, &smallCap, models.ConcentrationRiskMedium},
+		{45, &largeCap, models.ConcentrationRiskLow},
+		{20, &smallCap, models.ConcentrationRiskLow},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ConcentrationRisk(tt.top10, tt.marketCap), "top 10 holding %v%%", tt.top10)
+	}
+}
+
+func TestTokenHolderServiceAttach(t *testing.T) {
+	marketCap := 10_000_000.0
+	token := &models.Token{ID: uuid.New(), MarketCap: &marketCap}
+	userID := uuid.New()
+	repo := &storedHolders{
+		stats:   &models.TokenHolderStats{TokenID: token.ID, TotalSupply: "1000000", Top10SharePct: 82.5},
+		userID:  userID,
+		balance: "2500",
+	}
+	service := NewTokenHolderService(repo)
+
+	require.NoError(t, service.Attach(context.Background(), token, userID))
+	require.NotNil(t, token.Holders)
+	assert.Equal(t, models.ConcentrationRiskHigh, token.Holders.ConcentrationRisk)
+	require.NotNil(t, token.UserSupplyPct)
+	assert.InDelta(t, 0.25, *token.UserSupplyPct, 1e-9)
+
+	// Tokens whose holders weren't fetched get neither
+	other := &models.Token{ID: uuid.New()}
+	require.NoError(t, service.Attach(context.Background(), other, userID))
+	assert.Nil(t, other.Holders)
+	assert.Nil(t, other.UserSupplyPct)
+}
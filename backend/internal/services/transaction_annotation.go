@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAnnotationTags     = 20
+	maxAnnotationTagLen   = 32
+	maxAnnotationNotesLen = 2000
+)
+
+// transactionHashPattern matches on-chain hashes and those given to manual transactions
+var transactionHashPattern = regexp.MustCompile(`^(0x[0-9a-fA-F]{64}|manual-[0-9a-f]{32})$`)
+
+// SetAnnotation replaces the user's annotation on a transaction. An annotation with no
+// category, tags or notes is removed instead, returning nil.
+func (s *TransactionService) SetAnnotation(ctx context.Context, userID uuid.UUID, hash string, req *models.UpdateTransactionAnnotationRequest) (*models.TransactionAnnotation, error) {
+	if !transactionHashPattern.MatchString(hash) {
+		return nil, errors.BadRequest("Invalid transaction hash")
+	}
+
+	annotation := &models.TransactionAnnotation{
+		UserID:          userID,
+		TransactionHash: strings.ToLower(hash),
+		Tags:            []string{},
+	}
+
+	if req.Category != nil && *req.Category != "" {
+		category := *req.Category
+		if category != models.IncomeNotIncome && !pnl.IsIncomeCategory(category) {
+			return nil, errors.BadRequest("Invalid category. Use staking_reward, airdrop, interest, cashback, mining, other or not_income")
+		}
+		annotation.Category = &category
+	}
+
+	seen := make(map[string]bool)
+	for _, tag := range req.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxAnnotationTagLen {
+			return nil, errors.BadRequest(fmt.Sprintf("Tags must be at most %d characters", maxAnnotationTagLen))
+		}
+		seen[tag] = true
+		annotation.Tags = append(annotation.Tags, tag)
+	}
+	if len(annotation.Tags) > maxAnnotationTags {
+		return nil, errors.BadRequest(fmt.Sprintf("A transaction can have at most %d tags", maxAnnotationTags))
+	}
+
+	if req.Notes != nil {
+		notes := strings.TrimSpace(*req.Notes)
+		if len(notes) > maxAnnotationNotesLen {
+			return nil, errors.BadRequest(fmt.Sprintf("Notes must be at most %d characters", maxAnnotationNotesLen))
+		}
+		if notes != "" {
+			annotation.Notes = &notes
+		}
+	}
+
+	if annotation.Category == nil && len(annotation.Tags) == 0 && annotation.Notes == nil {
+		if _, err := s.annotationRepo.Delete(ctx, userID, annotation.TransactionHash); err != nil {
+			logger.Error("Failed to delete transaction annotation", "error", err, "hash", hash)
+			return nil, errors.Internal("Failed to update annotation")
+		}
+		return nil, nil
+	}
+
+	if err := s.annotationRepo.Upsert(ctx, annotation); err != nil {
+		logger.Error("Failed to save transaction annotation", "error", err, "hash", hash)
+		return nil, errors.Internal("Failed to update annotation")
+	}
+	return annotation, nil
+}
+
+// DeleteAnnotation removes the user's annotation on a transaction
+func (s *TransactionService) DeleteAnnotation(ctx context.Context, userID uuid.UUID, hash string) error {
+	found, err := s.annotationRepo.Delete(ctx, userID, strings.ToLower(hash))
+	if err != nil {
+		logger.Error("Failed to delete transaction annotation", "error", err, "hash", hash)
+		return errors.Internal("Failed to delete annotation")
+	}
+	if !found {
+		return errors.NotFound("Annotation")
+	}
+	return nil
+}
+
+// attachAnnotations sets the user's annotations on listed transactions. Listings are
+// still served if annotations can't be read.
+func (s *TransactionService) attachAnnotations(ctx context.Context, userID uuid.UUID, transactions []*models.Transaction) {
+	if len(transactions) == 0 {
+		return
+	}
+
+	hashes := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		hashes = append(hashes, strings.ToLower(tx.Hash))
+	}
+
+	annotations, err := s.annotationRepo.GetByHashes(ctx, userID, hashes)
+	if err != nil {
+		logger.Warn("Failed to load transaction annotations", "error", err, "userID", userID)
+		return
+	}
+	for _, tx := range transactions {
+		tx.Annotation = annotations[strings.ToLower(tx.Hash)]
+	}
+}
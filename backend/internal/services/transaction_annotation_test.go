@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryAnnotationRepo struct {
+	annotations map[string]*models.TransactionAnnotation
+}
+
+func (r *memoryAnnotationRepo) Upsert(ctx context.Context, annotation *models.TransactionAnnotation) error {
+	r.annotations[annotation.TransactionHash] = annotation
+	return nil
+}
+
+func (r *memoryAnnotationRepo) Delete(ctx context.Context, userID uuid.UUID, transactionHash string) (bool, error) {
+	_, ok := r.annotations[transactionHash]
+	delete(r.annotations, transactionHash)
+	return ok, nil
+}
+
+func (r *memoryAnnotationRepo) GetByHashes(ctx context.Context, userID uuid.UUID, hashes []string) (map[string]*models.TransactionAnnotation, error) {
+	found := make(map[string]*models.TransactionAnnotation)
+	for _, hash := range hashes {
+		if annotation, ok := r.annotations[hash]; ok {
+			found[hash] = annotation
+		}
+	}
+	return found, nil
+}
+
+func TestSetAnnotation(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryAnnotationRepo{annotations: make(map[string]*models.TransactionAnnotation)}
+	service := NewTransactionService(nil, repo)
+	userID := uuid.New()
+	hash := "0x" + strings.Repeat("AB", 32)
+
+	category := models.IncomeAirdrop
+	notes := "  claimed after the snapshot "
+	annotation, err := service.SetAnnotation(ctx, userID, hash, &models.UpdateTransactionAnnotationRequest{
+		Category: &category,
+		Tags:     []string{"Tax", "tax", " defi ", ""},
+		Notes:    &notes,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToLower(hash), annotation.TransactionHash)
+	assert.Equal(t, []string{"tax", "defi"}, annotation.Tags)
+	assert.Equal(t, "claimed after the snapshot", *annotation.Notes)
+
+	transactions := []*models.Transaction{{Hash: hash}, {Hash: "0x" + strings.Repeat("cd", 32)}}
+	service.attachAnnotations(ctx, userID, transactions)
+	assert.Equal(t, annotation, transactions[0].Annotation)
+	assert.Nil(t, transactions[1].Annotation)
+
+	// Clearing every field removes the annotation
+	annotation, err = service.SetAnnotation(ctx, userID, hash, &models.UpdateTransactionAnnotationRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, annotation)
+	assert.Empty(t, repo.annotations)
+	assert.Error(t, service.DeleteAnnotation(ctx, userID, hash))
+}
+
+func TestSetAnnotation_Invalid(t *testing.T) {
+	ctx := context.Background()
+	service := NewTransactionService(nil, &memoryAnnotationRepo{annotations: make(map[string]*models.TransactionAnnotation)})
+	userID := uuid.New()
+	hash := "0x" + strings.Repeat("ab", 32)
+
+	_, err := service.SetAnnotation(ctx, userID, "0x1234", &models.UpdateTransactionAnnotationRequest{})
+	assert.Error(t, err, "malformed hash")
+
+	category := "salary"
+	_, err = service.SetAnnotation(ctx, userID, hash, &models.UpdateTransactionAnnotationRequest{Category: &category})
+	assert.Error(t, err, "unknown category")
+
+	_, err = service.SetAnnotation(ctx, userID, hash, &models.UpdateTransactionAnnotationRequest{Tags: []string{strings.Repeat("x", 33)}})
+	assert.Error(t, err, "tag too long")
+
+	// Manual transactions can be annotated too
+	imp := &models.TransactionImport{ID: uuid.New()}
+	wallet := &models.Wallet{ID: uuid.New(), Address: "0x1234567890123456789012345678901234567890", ChainID: 1}
+	manual := manualTransaction(imp, wallet, models.TransactionImportRow{Type: models.ManualTypeBuy, Quantity: 1, PriceUSD: 1}, uuid.New())
+	assert.True(t, transactionHashPattern.MatchString(manual.Transaction.Hash))
+}
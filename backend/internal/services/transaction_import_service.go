@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/csvimport"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// MaxImportSize is the largest CSV accepted for import
+const MaxImportSize = 2 << 20
+
+// importSampleRows is how many raw rows are returned with an upload to help map columns
+const importSampleRows = 5
+
+var evmAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// TransactionImportService imports manual and off-chain transactions (OTC trades, gifts,
+// mining income) from CSV in three steps: upload, preview with a column mapping, and
+// commit, which creates the transactions and their PnL lots flagged as manual
+type TransactionImportService struct {
+	importRepo    repos.TransactionImportRepository
+	walletRepo    repos.WalletRepository
+	uploadService *UploadService
+}
+
+func NewTransactionImportService(importRepo repos.TransactionImportRepository, walletRepo repos.WalletRepository, uploadService *UploadService) *TransactionImportService {
+	return &TransactionImportService{
+		importRepo:    importRepo,
+		walletRepo:    walletRepo,
+		uploadService: uploadService,
+	}
+}
+
+// Upload stores a CSV for import into one of the user's wallets and returns its columns
+// with a suggested mapping
+func (s *TransactionImportService) Upload(ctx context.Context, userID, walletID uuid.UUID, filename string, data []byte) (*models.TransactionImportPreview, error) {
+	if len(data) > MaxImportSize {
+		return nil, errors.BadRequest(fmt.Sprintf("File is larger than %d MB", MaxImportSize>>20))
+	}
+	if _, err := s.userWallet(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	file, err := csvimport.Parse(data)
+	if err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("Invalid CSV: %v", err))
+	}
+
+	upload, err := s.uploadService.Save(ctx, userID, models.UploadKindImport, filename, "text/csv", data)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &models.TransactionImport{
+		UserID:   userID,
+		WalletID: walletID,
+		UploadID: &upload.ID,
+		Filename: filename,
+		Status:   models.ImportStatusPending,
+		RowCount: len(file.Rows),
+	}
+	if err := s.importRepo.Create(ctx, imp); err != nil {
+		logger.Error("Failed to create transaction import", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to create import")
+	}
+
+	sample := file.Rows
+	if len(sample) > importSampleRows {
+		sample = sample[:importSampleRows]
+	}
+	return &models.TransactionImportPreview{
+		Import:           imp,
+		Columns:          file.Columns,
+		SuggestedMapping: csvimport.SuggestMapping(file.Columns),
+		Sample:           sample,
+	}, nil
+}
+
+// Get returns one of the user's imports
+func (s *TransactionImportService) Get(ctx context.Context, userID, importID uuid.UUID) (*models.TransactionImport, error) {
+	imp, err := s.importRepo.GetByID(ctx, userID, importID)
+	if err != nil {
+		logger.Error("Failed to get transaction import", "error", err, "importID", importID)
+		return nil, errors.Internal("Failed to fetch import")
+	}
+	if imp == nil {
+		return nil, errors.NotFound("Import")
+	}
+	return imp, nil
+}
+
+// Preview validates every row with the given column mapping and saves the mapping for
+// the commit
+func (s *TransactionImportService) Preview(ctx context.Context, userID, importID uuid.UUID, mapping map[string]string) (*models.TransactionImportPreview, error) {
+	imp, err := s.Get(ctx, userID, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status == models.ImportStatusCommitted {
+		return nil, errors.Conflict("Import has already been committed")
+	}
+
+	file, wallet, err := s.load(ctx, imp)
+	if err != nil {
+		return nil, err
+	}
+	if err := csvimport.ValidateMapping(mapping, file.Columns); err != nil {
+		return nil, errors.BadRequest(fmt.Sprintf("Invalid mapping: %v", err))
+	}
+	rows, _, err := s.parseRows(ctx, wallet, file, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	imp.Mapping = mapping
+	imp.Status = models.ImportStatusPreviewed
+	if err := s.importRepo.SavePreview(ctx, imp); err != nil {
+		logger.Error("Failed to save transaction import preview", "error", err, "importID", importID)
+		return nil, errors.Internal("Failed to save import mapping")
+	}
+
+	preview := &models.TransactionImportPreview{
+		Import:           imp,
+		Columns:          file.Columns,
+		SuggestedMapping: csvimport.SuggestMapping(file.Columns),
+		Rows:             rows,
+	}
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			preview.ValidCount++
+		} else {
+			preview.InvalidCount++
+		}
+	}
+	return preview, nil
+}
+
+// Commit creates the transactions and lots of a previewed import. Invalid rows make it
+// fail unless skipInvalid is set, in which case only the valid rows are imported.
+func (s *TransactionImportService) Commit(ctx context.Context, userID, importID uuid.UUID, skipInvalid bool) (*models.TransactionImport, error) {
+	imp, err := s.Get(ctx, userID, importID)
+	if err != nil {
+		return nil, err
+	}
+	switch imp.Status {
+	case models.ImportStatusCommitted:
+		return nil, errors.Conflict("Import has already been committed")
+	case models.ImportStatusPending:
+		return nil, errors.BadRequest("Preview the import with a column mapping before committing it")
+	}
+
+	file, wallet, err := s.load(ctx, imp)
+	if err != nil {
+		return nil, err
+	}
+	rows, tokens, err := s.parseRows(ctx, wallet, file, imp.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []repos.ManualTransaction
+	invalid := 0
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			invalid++
+			continue
+		}
+		entries = append(entries, manualTransaction(imp, wallet, row, tokens[assetKey(row.Asset)]))
+	}
+	if invalid > 0 && !skipInvalid {
+		return nil, errors.BadRequest(fmt.Sprintf("%d rows are invalid; fix them or commit with skip_invalid", invalid))
+	}
+	if len(entries) == 0 {
+		return nil, errors.BadRequest("No valid rows to import")
+	}
+
+	committed, err := s.importRepo.Commit(ctx, imp, entries)
+	if err != nil {
+		logger.Error("Failed to commit transaction import", "error", err, "importID", importID)
+		return nil, errors.Internal("Failed to import transactions")
+	}
+	if !committed {
+		return nil, errors.Conflict("Import has already been committed")
+	}
+
+	logger.Info("Imported manual transactions", "userID", userID, "importID", importID, "imported", len(entries), "skipped", invalid)
+	return imp, nil
+}
+
+// load reads an import's CSV and the wallet it goes into
+func (s *TransactionImportService) load(ctx context.Context, imp *models.TransactionImport) (*csvimport.File, *models.Wallet, error) {
+	wallet, err := s.userWallet(ctx, imp.UserID, imp.WalletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imp.UploadID == nil {
+		return nil, nil, errors.BadRequest("The imported file has expired; upload it again")
+	}
+	upload, err := s.uploadService.Get(ctx, imp.UserID, *imp.UploadID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == "NOT_FOUND" {
+			return nil, nil, errors.BadRequest("The imported file has expired; upload it again")
+		}
+		return nil, nil, err
+	}
+	data, err := s.uploadService.Read(ctx, upload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := csvimport.Parse(data)
+	if err != nil {
+		return nil, nil, errors.BadRequest(fmt.Sprintf("Invalid CSV: %v", err))
+	}
+	return file, wallet, nil
+}
+
+// parseRows parses the rows with mapping and resolves their assets to tokens on the
+// wallet's chain, flagging rows whose asset is unknown
+func (s *TransactionImportService) parseRows(ctx context.Context, wallet *models.Wallet, file *csvimport.File, mapping map[string]string) ([]models.TransactionImportRow, map[string]uuid.UUID, error) {
+	rows := file.Records(mapping)
+
+	var addresses, symbols []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		key := assetKey(row.Asset)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if evmAddressPattern.MatchString(key) {
+			addresses = append(addresses, key)
+		} else {
+			symbols = append(symbols, key)
+		}
+	}
+
+	tokens, err := s.importRepo.ResolveTokens(ctx, wallet.ChainID, addresses, symbols)
+	if err != nil {
+		logger.Error("Failed to resolve import tokens", "error", err, "chainID", wallet.ChainID)
+		return nil, nil, errors.Internal("Failed to resolve tokens")
+	}
+
+	for i := range rows {
+		if rows[i].Asset == "" {
+			continue
+		}
+		if _, ok := tokens[assetKey(rows[i].Asset)]; !ok {
+			rows[i].Errors = append(rows[i].Errors, fmt.Sprintf(
+				"unknown or ambiguous asset %q on chain %d; use the token's contract address", rows[i].Asset, wallet.ChainID))
+		}
+	}
+	return rows, tokens, nil
+}
+
+// userWallet returns one of the user's wallets that manual transactions can go into
+func (s *TransactionImportService) userWallet(ctx context.Context, userID, walletID uuid.UUID) (*models.Wallet, error) {
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+	for _, wallet := range wallets {
+		if wallet.ID != walletID {
+			continue
+		}
+		if !wallet.IsEVM() {
+			return nil, errors.BadRequest("Manual transactions can only be imported into EVM wallets")
+		}
+		return wallet, nil
+	}
+	return nil, errors.NotFound("Wallet")
+}
+
+// manualTransaction builds the transaction and lot for a valid row. Acquisitions (buys,
+// gifts received, income) are buy lots and disposals sell lots; fees raise the cost of
+// an acquisition and reduce the proceeds of a disposal.
+func manualTransaction(imp *models.TransactionImport, wallet *models.Wallet, row models.TransactionImportRow, tokenID uuid.UUID) repos.ManualTransaction {
+	hash := "manual-" + strings.ReplaceAll(uuid.New().String(), "-", "")
+
+	lotType, txType := "buy", "receive"
+	price := row.PriceUSD + row.FeeUSD/row.Quantity
+	if row.Type == models.ManualTypeSell || row.Type == models.ManualTypeGiftOut {
+		lotType, txType = "sell", "send"
+		price = row.PriceUSD - row.FeeUSD/row.Quantity
+		if price < 0 {
+			price = 0
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"manual_type": row.Type,
+		"asset":       row.Asset,
+		"quantity":    row.Quantity,
+		"price_usd":   row.PriceUSD,
+		"fee_usd":     row.FeeUSD,
+		"notes":       row.Notes,
+		"import_id":   imp.ID.String(),
+		"import_row":  row.Row,
+	}
+	if row.IncomeCategory != "" {
+		metadata["income_category"] = row.IncomeCategory
+	}
+
+	address := wallet.Address
+	quantity := fmt.Sprintf("%.18f", row.Quantity)
+	return repos.ManualTransaction{
+		Transaction: &models.Transaction{
+			Hash:        hash,
+			ChainID:     wallet.ChainID,
+			FromAddress: wallet.Address,
+			ToAddress:   &address,
+			Timestamp:   row.Timestamp,
+			Status:      models.TransactionStatusConfirmed,
+			Type:        txType,
+			Source:      models.SourceManual,
+			Metadata:    metadata,
+		},
+		Lot: &models.PnLLot{
+			ID:                uuid.New(),
+			WalletID:          wallet.ID,
+			TokenID:           tokenID,
+			TransactionHash:   hash,
+			ChainID:           wallet.ChainID,
+			Type:              lotType,
+			Quantity:          quantity,
+			PriceUSD:          fmt.Sprintf("%.10f", price),
+			RemainingQuantity: quantity,
+			Timestamp:         row.Timestamp,
+			Source:            models.SourceManual,
+		},
+	}
+}
+
+// assetKey normalizes an asset for token lookup: addresses lowercase, symbols uppercase
+func assetKey(asset string) string {
+	asset = strings.TrimSpace(asset)
+	if evmAddressPattern.MatchString(asset) {
+		return strings.ToLower(asset)
+	}
+	return strings.ToUpper(asset)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssetKey(t *testing.T) {
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", assetKey("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"))
+	assert.Equal(t, "ETH", assetKey(" eth "))
+}
+
+func TestManualTransaction(t *testing.T) {
+	imp := &models.TransactionImport{ID: uuid.New()}
+	wallet := &models.Wallet{ID: uuid.New(), Address: "0x1234567890123456789012345678901234567890", ChainID: 1}
+	tokenID := uuid.New()
+	at := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	buy := manualTransaction(imp, wallet, models.TransactionImportRow{
+		Row: 2, Timestamp: at, Type: models.ManualTypeBuy, Asset: "ETH", Quantity: 2, PriceUSD: 2500, FeeUSD: 10,
+	}, tokenID)
+	assert.Equal(t, "receive", buy.Transaction.Type)
+	assert.Equal(t, models.SourceManual, buy.Transaction.Source)
+	assert.LessOrEqual(t, len(buy.Transaction.Hash), 66)
+	assert.Equal(t, buy.Transaction.Hash, buy.Lot.TransactionHash)
+	assert.Equal(t, "buy", buy.Lot.Type)
+	assert.Equal(t, "2505.0000000000", buy.Lot.PriceUSD, "fees raise the cost basis")
+	assert.Equal(t, buy.Lot.Quantity, buy.Lot.RemainingQuantity)
+	assert.Equal(t, models.SourceManual, buy.Lot.Source)
+	assert.Equal(t, tokenID, buy.Lot.TokenID)
+
+	sell := manualTransaction(imp, wallet, models.TransactionImportRow{
+		Row: 3, Timestamp: at, Type: models.ManualTypeSell, Asset: "ETH", Quantity: 2, PriceUSD: 3000, FeeUSD: 10,
+	}, tokenID)
+	assert.Equal(t, "send", sell.Transaction.Type)
+	assert.Equal(t, "sell", sell.Lot.Type)
+	assert.Equal(t, "2995.0000000000", sell.Lot.PriceUSD, "fees reduce the proceeds")
+	assert.NotEqual(t, buy.Transaction.Hash, sell.Transaction.Hash)
+
+	gift := manualTransaction(imp, wallet, models.TransactionImportRow{
+		Row: 4, Timestamp: at, Type: models.ManualTypeGiftOut, Asset: "ETH", Quantity: 1, FeeUSD: 5,
+	}, tokenID)
+	assert.Equal(t, "0.0000000000", gift.Lot.PriceUSD)
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// TransactionSearchResponse is a page of search results
+type TransactionSearchResponse struct {
+	Data       []*models.Transaction `json:"data"`
+	NextCursor *string               `json:"next_cursor"`
+}
+
+var validTransactionTypes = map[string]bool{
+	"send": true, "receive": true, "swap": true,
+	"approve": true, "bridge": true, "stake": true, "unstake": true,
+}
+
+var validTransactionStatuses = map[string]bool{
+	models.TransactionStatusPending:   true,
+	models.TransactionStatusConfirmed: true,
+	models.TransactionStatusFailed:    true,
+	models.TransactionStatusDropped:   true,
+}
+
+// SearchTransactions searches the user's stored transactions with the filter DSL
+// described in parseTransactionQuery, paginated by an opaque cursor
+func (s *TransactionService) SearchTransactions(ctx context.Context, userID uuid.UUID, query, cursor string, limit int) (*TransactionSearchResponse, error) {
+	filters, err := parseTransactionQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor != "" {
+		c, err := decodeTransactionCursor(cursor)
+		if err != nil {
+			return nil, errors.BadRequest("Invalid cursor")
+		}
+		filters.Cursor = c
+	}
+
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	// Fetch one extra row to know whether there is another page
+	filters.Limit = limit + 1
+
+	transactions, err := s.transactionRepo.Search(ctx, userID, filters)
+	if err != nil {
+		logger.Error("Failed to search transactions", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to search transactions")
+	}
+
+	setRequiredConfirmations(transactions)
+
+	resp := &TransactionSearchResponse{Data: transactions}
+	if len(transactions) > limit {
+		resp.Data = transactions[:limit]
+		last := resp.Data[limit-1]
+		next := encodeTransactionCursor(&repos.TransactionCursor{Timestamp: last.Timestamp, ID: last.ID})
+		resp.NextCursor = &next
+	}
+	s.attachAnnotations(ctx, userID, resp.Data)
+	if resp.Data == nil {
+		resp.Data = []*models.Transaction{}
+	}
+
+	return resp, nil
+}
+
+// parseTransactionQuery parses the search DSL into filters. A query is a list of
+// space-separated terms; values containing spaces can be double-quoted.
+//
+//	chain:1,137            chain IDs
+//	type:swap,approve      transaction types
+//	status:pending         transaction statuses (pending, confirmed, failed, dropped)
+//	token:0xA0b8...        token contract involved
+//	label:"cold storage"   counterparty label (address labels and wallet labels)
+//	tag:tax,defi           annotation tags (any of them)
+//	value>=1000 value<5000 value range in base units (>, >=, <, <=)
+//	date>=2024-01-01       date range, YYYY-MM-DD or RFC3339 (>, >=, <, <=)
+//	anything else          free text over decoded method names
+func parseTransactionQuery(query string) (repos.TransactionSearchFilters, error) {
+	var filters repos.TransactionSearchFilters
+
+	for _, term := range splitQueryTerms(query) {
+		key, op, value := splitQueryTerm(term)
+		if key == "" {
+			filters.Text = append(filters.Text, textWords(term)...)
+			continue
+		}
+		if value == "" {
+			return filters, errors.BadRequest(fmt.Sprintf("Missing value for %s", key))
+		}
+
+		switch key {
+		case "chain":
+			if op != ":" {
+				return filters, errors.BadRequest("chain only supports ':'")
+			}
+			for _, v := range strings.Split(value, ",") {
+				chainID, err := strconv.Atoi(v)
+				if err != nil {
+					return filters, errors.BadRequest(fmt.Sprintf("Invalid chain: %s", v))
+				}
+				filters.ChainIDs = append(filters.ChainIDs, chainID)
+			}
+		case "type":
+			if op != ":" {
+				return filters, errors.BadRequest("type only supports ':'")
+			}
+			for _, v := range strings.Split(value, ",") {
+				v = strings.ToLower(v)
+				if !validTransactionTypes[v] {
+					return filters, errors.BadRequest(fmt.Sprintf("Invalid transaction type: %s", v))
+				}
+				filters.Types = append(filters.Types, v)
+			}
+		case "status":
+			if op != ":" {
+				return filters, errors.BadRequest("status only supports ':'")
+			}
+			for _, v := range strings.Split(value, ",") {
+				v = strings.ToLower(v)
+				if !validTransactionStatuses[v] {
+					return filters, errors.BadRequest(fmt.Sprintf("Invalid transaction status: %s", v))
+				}
+				filters.Statuses = append(filters.Statuses, v)
+			}
+		case "token":
+			if op != ":" {
+				return filters, errors.BadRequest("token only supports ':'")
+			}
+			token := strings.ToLower(value)
+			filters.Token = &token
+		case "label":
+			if op != ":" {
+				return filters, errors.BadRequest("label only supports ':'")
+			}
+			label := escapeLikePattern(value)
+			filters.CounterpartyLabel = &label
+		case "tag":
+			if op != ":" {
+				return filters, errors.BadRequest("tag only supports ':'")
+			}
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+					filters.Tags = append(filters.Tags, v)
+				}
+			}
+		case "value":
+			if err := applyValueBound(&filters, op, value); err != nil {
+				return filters, err
+			}
+		case "date":
+			if err := applyDateBound(&filters, op, value); err != nil {
+				return filters, err
+			}
+		default:
+			return filters, errors.BadRequest(fmt.Sprintf("Unknown search filter: %s", key))
+		}
+	}
+
+	return filters, nil
+}
+
+// splitQueryTerms splits on whitespace outside double quotes and strips the quotes
+func splitQueryTerms(query string) []string {
+	var terms []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+
+	return terms
+}
+
+// splitQueryTerm splits "key<op>value" on the first operator; key is "" for free text
+func splitQueryTerm(term string) (key, op, value string) {
+	i := strings.IndexAny(term, ":<>")
+	if i <= 0 {
+		return "", "", term
+	}
+
+	key = strings.ToLower(term[:i])
+	for _, candidate := range []string{">=", "<=", ":", ">", "<"} {
+		if strings.HasPrefix(term[i:], candidate) {
+			return key, candidate, term[i+len(candidate):]
+		}
+	}
+	return "", "", term
+}
+
+// textWords splits a free-text term the way method_search splits method names: on
+// anything that isn't a letter or digit, and where a lowercase letter or digit is
+// followed by an uppercase one, so "swapExact" searches for "swap" and "Exact"
+func textWords(term string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		start := 0
+		runes := []rune(field)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+func applyValueBound(filters *repos.TransactionSearchFilters, op, value string) error {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok || v.Sign() < 0 {
+		return errors.BadRequest(fmt.Sprintf("Invalid value: %s (expected a non-negative integer in base units)", value))
+	}
+
+	// Values are integers, so strict bounds become inclusive ones
+	switch op {
+	case ">":
+		v.Add(v, big.NewInt(1))
+		fallthrough
+	case ">=":
+		min := v.String()
+		filters.MinValue = &min
+	case "<":
+		v.Sub(v, big.NewInt(1))
+		fallthrough
+	case "<=":
+		max := v.String()
+		filters.MaxValue = &max
+	default:
+		return errors.BadRequest("value only supports >, >=, <, <=")
+	}
+	return nil
+}
+
+func applyDateBound(filters *repos.TransactionSearchFilters, op, value string) error {
+	t, dateOnly, err := parseSearchDate(value)
+	if err != nil {
+		return errors.BadRequest(fmt.Sprintf("Invalid date: %s (expected YYYY-MM-DD or RFC3339)", value))
+	}
+
+	// A bare date covers the whole day
+	next := t
+	if dateOnly {
+		next = t.AddDate(0, 0, 1)
+	}
+
+	switch op {
+	case ">=":
+		filters.From = &t
+	case ">":
+		if !dateOnly {
+			next = t.Add(time.Nanosecond)
+		}
+		filters.From = &next
+	case "<":
+		filters.To = &t
+	case "<=":
+		if !dateOnly {
+			next = t.Add(time.Nanosecond)
+		}
+		filters.To = &next
+	case ":":
+		if !dateOnly {
+			return errors.BadRequest("date: requires a YYYY-MM-DD date")
+		}
+		filters.From = &t
+		filters.To = &next
+	}
+	return nil
+}
+
+func parseSearchDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// escapeLikePattern escapes ILIKE wildcards so labels match literally
+func escapeLikePattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+func encodeTransactionCursor(c *repos.TransactionCursor) string {
+	raw := strconv.FormatInt(c.Timestamp.UnixNano(), 10) + "_" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTransactionCursor(cursor string) (*repos.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repos.TransactionCursor{Timestamp: time.Unix(0, nanos).UTC(), ID: parsedID}, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransactionQuery(t *testing.T) {
+	filters, err := parseTransactionQuery(`chain:1,137 type:swap status:Pending,dropped token:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48 label:"cold 100%" value>1000 value<=5000 date>=2024-01-01 date<=2024-01-31 swapExact tokens`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 137}, filters.ChainIDs)
+	assert.Equal(t, []string{"swap"}, filters.Types)
+	assert.Equal(t, []string{"pending", "dropped"}, filters.Statuses)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", *filters.Token)
+	assert.Equal(t, `cold 100\%`, *filters.CounterpartyLabel)
+	assert.Equal(t, "1001", *filters.MinValue)
+	assert.Equal(t, "5000", *filters.MaxValue)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *filters.From)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *filters.To)
+	assert.Equal(t, []string{"swap", "Exact", "tokens"}, filters.Text)
+}
+
+func TestTextWords(t *testing.T) {
+	// Split like the method_search column, so camelCase queries match their words
+	assert.Equal(t, []string{"swap", "Exact", "Tokens", "For", "ETH"}, textWords("swapExactTokensForETH"))
+	assert.Equal(t, []string{"multicall", "2", "Fee"}, textWords("multicall(2Fee)"))
+	assert.Equal(t, []string{"ERC", "20", "Transfer"}, textWords("ERC-20Transfer"))
+	assert.Equal(t, []string{"approve"}, textWords("approve"))
+	assert.Empty(t, textWords("&|!"))
+}
+
+func TestParseTransactionQuery_Tags(t *testing.T) {
+	filters, err := parseTransactionQuery("tag:Tax,defi,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tax", "defi"}, filters.Tags)
+
+	_, err = parseTransactionQuery("tag>tax")
+	assert.Error(t, err)
+}
+
+func TestParseTransactionQuery_Errors(t *testing.T) {
+	for _, q := range []string{
+		"chain:mainnet",
+		"type:mint",
+		"status:mined",
+		"value>1.5",
+		"date>=yesterday",
+		"color:red",
+		"token:",
+		"chain>1",
+	} {
+		_, err := parseTransactionQuery(q)
+		assert.Error(t, err, q)
+	}
+}
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	cursor := &repos.TransactionCursor{
+		Timestamp: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := decodeTransactionCursor(encodeTransactionCursor(cursor))
+	require.NoError(t, err)
+	assert.True(t, cursor.Timestamp.Equal(decoded.Timestamp))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	_, err = decodeTransactionCursor("not-a-cursor")
+	assert.Error(t, err)
+}
@@ -3,11 +3,13 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/clock"
 	"github.com/defi-dashboard/backend/pkg/errors"
 	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/google/uuid"
@@ -15,16 +17,21 @@ import (
 
 type TransactionService struct {
 	transactionRepo repos.TransactionRepository
+	annotationRepo  repos.TransactionAnnotationRepository
+	clock           clock.Clock
 }
 
-func NewTransactionService(transactionRepo repos.TransactionRepository) *TransactionService {
+func NewTransactionService(transactionRepo repos.TransactionRepository, annotationRepo repos.TransactionAnnotationRepository) *TransactionService {
 	return &TransactionService{
 		transactionRepo: transactionRepo,
+		annotationRepo:  annotationRepo,
+		clock:           clock.System,
 	}
 }
 
-// GetTransactions returns real transactions for an address from blockchain
-func (s *TransactionService) GetTransactions(ctx context.Context, address string, chainID *int, txType *string, page, limit int, alchemyAPIKey, coinGeckoAPIKey string) (*TransactionResponse, error) {
+// GetTransactions returns real transactions for an address from blockchain, with the
+// user's annotations
+func (s *TransactionService) GetTransactions(ctx context.Context, userID uuid.UUID, address string, chainID *int, txType *string, page, limit int, alchemyAPIKey, coinGeckoAPIKey string) (*TransactionResponse, error) {
 	logger.Info("Fetching transactions", "address", address, "chainID", chainID, "type", txType)
 
 	// Default to Ethereum mainnet if no chain specified
@@ -68,6 +75,25 @@ func (s *TransactionService) GetTransactions(ctx context.Context, address string
 		transactions = filteredTxs
 	}
 
+	// Transactions still awaiting finality (or recently dropped) come from our own tracking,
+	// since the provider only reports mined transfers
+	unfinalized, err := s.transactionRepo.GetUnfinalizedByAddress(ctx, address, chain)
+	if err != nil {
+		logger.Warn("Failed to load unfinalized transactions", "address", address, "chainID", chain, "error", err)
+	} else {
+		if txType != nil {
+			filtered := make([]*models.Transaction, 0, len(unfinalized))
+			for _, tx := range unfinalized {
+				if tx.Type == *txType {
+					filtered = append(filtered, tx)
+				}
+			}
+			unfinalized = filtered
+		}
+		transactions = mergeUnfinalized(unfinalized, transactions)
+	}
+	setRequiredConfirmations(transactions)
+
 	// Apply pagination
 	offset := (page - 1) * limit
 	total := len(transactions)
@@ -82,6 +108,9 @@ func (s *TransactionService) GetTransactions(ctx context.Context, address string
 		transactions = transactions[offset:end]
 	}
 
+	s.attachStoredGasFees(ctx, chain, transactions)
+	s.attachAnnotations(ctx, userID, transactions)
+
 	// Store transactions in database for caching (optional)
 	if err := s.storeTransactions(ctx, address, chain, transactions); err != nil {
 		logger.Error("Failed to store transactions in database", "error", err)
@@ -105,6 +134,47 @@ func (s *TransactionService) GetTransactions(ctx context.Context, address string
 	}, nil
 }
 
+// mergeUnfinalized lists unfinalized transactions first, replacing the provider's copy of
+// any that were mined since so their confirmation progress is kept
+func mergeUnfinalized(unfinalized, mined []*models.Transaction) []*models.Transaction {
+	if len(unfinalized) == 0 {
+		return mined
+	}
+
+	tracked := make(map[string]bool, len(unfinalized))
+	for _, tx := range unfinalized {
+		tracked[strings.ToLower(tx.Hash)] = true
+	}
+
+	merged := append([]*models.Transaction{}, unfinalized...)
+	for _, tx := range mined {
+		if !tracked[strings.ToLower(tx.Hash)] {
+			merged = append(merged, tx)
+		}
+	}
+	return merged
+}
+
+// setRequiredConfirmations annotates pending transactions with their chain's finality threshold
+func setRequiredConfirmations(transactions []*models.Transaction) {
+	for _, tx := range transactions {
+		if tx.Status == models.TransactionStatusPending {
+			tx.RequiredConfirmations = blockchain.FinalityThreshold(tx.ChainID)
+		}
+	}
+}
+
+// GetProtocolInteractions returns which protocols the user's wallets at address have
+// dealt with, with transaction counts, volume and last interaction per chain
+func (s *TransactionService) GetProtocolInteractions(ctx context.Context, userID uuid.UUID, address string) ([]*models.ProtocolInteraction, error) {
+	interactions, err := s.transactionRepo.GetProtocolInteractions(ctx, userID, address)
+	if err != nil {
+		logger.Error("Failed to get protocol interactions", "address", address, "error", err)
+		return nil, errors.Internal("Failed to get protocol interactions")
+	}
+	return interactions, nil
+}
+
 // GetApprovals returns token approvals for an address (placeholder - requires specialized API)
 func (s *TransactionService) GetApprovals(ctx context.Context, address string, chainID *int, activeOnly bool) ([]*TokenApproval, error) {
 	logger.Info("Fetching token approvals", "address", address, "chainID", chainID)
@@ -132,6 +202,40 @@ func (s *TransactionService) RevokeApproval(ctx context.Context, address, token,
 	return "", errors.BadRequest("Approval revocation not yet implemented - requires wallet integration")
 }
 
+// attachStoredGasFees fills in the gas fees ingestion and the gas fee backfill stored for
+// the transactions. Those not enriched yet are returned without one rather than looked
+// up during the request.
+func (s *TransactionService) attachStoredGasFees(ctx context.Context, chainID int, transactions []*models.Transaction) {
+	hashes := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		if tx.GasFeeUSD == nil {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return
+	}
+
+	stored, err := s.transactionRepo.GetByHashes(ctx, chainID, hashes)
+	if err != nil {
+		logger.Warn("Failed to load stored gas fees", "chainID", chainID, "error", err)
+		return
+	}
+	for _, tx := range transactions {
+		saved, ok := stored[strings.ToLower(tx.Hash)]
+		if !ok || tx.GasFeeUSD != nil {
+			continue
+		}
+		if tx.GasUsed == nil {
+			tx.GasUsed = saved.GasUsed
+		}
+		if tx.GasPrice == nil {
+			tx.GasPrice = saved.GasPrice
+		}
+		tx.GasFeeUSD = saved.GasFeeUSD
+	}
+}
+
 // storeTransactions stores transaction data in database for caching
 func (s *TransactionService) storeTransactions(ctx context.Context, address string, chainID int, transactions []*models.Transaction) error {
 	// This is optional - for caching and historical tracking
@@ -184,7 +288,7 @@ func (s *TransactionService) GetTransactionsSummary(ctx context.Context, address
 	}
 
 	// Calculate summary
-	cutoff := time.Now().AddDate(0, 0, -days)
+	cutoff := s.clock.Now().AddDate(0, 0, -days)
 	summary := &TransactionSummary{
 		Period:            days,
 		TotalTransactions: 0,
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// Gas a plain send takes, of the native token and of an ERC-20
+	nativeSendGas = 21_000
+	tokenSendGas  = 65_000
+
+	// maxTransferPlanQuotes caps the holdings quoted for a swap or bridge per plan, the
+	// largest first, as each asks every aggregator
+	maxTransferPlanQuotes = 4
+	// transferSizingMargin is the share over the amount's value a swap or bridge is sized
+	// with, on top of slippage, so fees don't leave the recipient short
+	transferSizingMargin = 0.02
+)
+
+// Ways a transfer plan delivers
+const (
+	TransferDirect         = "direct"
+	TransferSwapThenSend   = "swap_then_send"
+	TransferBridgeThenSend = "bridge_then_send"
+)
+
+type TransferPlanRequest struct {
+	Recipient string `json:"recipient"`
+	ChainID   int    `json:"chainId"`
+	Token     string `json:"token"`
+	// Amount is what the recipient receives, in base units of Token
+	Amount   string  `json:"amount"`
+	Slippage float64 `json:"slippage,omitempty"`
+}
+
+// TransferStep is one transaction of a transfer option: a swap or bridge into the
+// token to send, or the send itself
+type TransferStep struct {
+	Type       string `json:"type"`
+	Provider   string `json:"provider,omitempty"`
+	FromChain  int    `json:"fromChain"`
+	ToChain    int    `json:"toChain"`
+	FromToken  string `json:"fromToken"`
+	ToToken    string `json:"toToken"`
+	FromAmount string `json:"fromAmount"`
+	ToAmount   string `json:"toAmount"`
+	// To is who a send goes to
+	To      string  `json:"to,omitempty"`
+	CostUSD float64 `json:"costUsd"`
+	GasUSD  float64 `json:"gasUsd"`
+}
+
+// TransferOption is one way to deliver a transfer from one of the user's holdings
+type TransferOption struct {
+	Kind string `json:"kind"`
+	// Wallet is the user's address the funds leave from
+	Wallet     string         `json:"wallet"`
+	FromChain  int            `json:"fromChain"`
+	FromToken  string         `json:"fromToken"`
+	FromAmount string         `json:"fromAmount"`
+	Steps      []TransferStep `json:"steps"`
+	// CostUSD is the value lost to fees and price impact, GasUSD what the steps burn
+	CostUSD      float64 `json:"costUsd"`
+	GasUSD       float64 `json:"gasUsd"`
+	TotalCostUSD float64 `json:"totalCostUsd"`
+}
+
+type TransferPlan struct {
+	Recipient string   `json:"recipient"`
+	ChainID   int      `json:"chainId"`
+	Token     string   `json:"token"`
+	Amount    string   `json:"amount"`
+	AmountUSD *float64 `json:"amountUsd,omitempty"`
+	// Options are the ways to deliver, cheapest first
+	Options []TransferOption `json:"options"`
+	// Warnings lists holdings that couldn't be planned from and costs left out
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type transferPlanWallets interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Wallet, error)
+}
+
+type transferPlanHoldings interface {
+	GetCurrentHoldings(ctx context.Context, walletIDs []uuid.UUID) ([]*models.WalletHolding, error)
+}
+
+// TransferPlanner plans how to get an amount of a token to anyone on a chain from
+// whatever the user holds: a direct send, a swap then a send on the same chain, or a
+// bridge then a send, comparing what each costs
+type TransferPlanner struct {
+	wallets  transferPlanWallets
+	holdings transferPlanHoldings
+	tokens   yieldEstimateTokens
+	swaps    yieldEstimateSwaps
+	bridges  yieldEstimateBridges
+	// chainCosts builds the gas pricer for a request with the caller's Alchemy key
+	chainCosts func(alchemyAPIKey string) ChainCosts
+}
+
+func NewTransferPlanner(wallets transferPlanWallets, holdings transferPlanHoldings, tokens yieldEstimateTokens, swaps *SwapService, bridges *BridgeService) *TransferPlanner {
+	return &TransferPlanner{
+		wallets:  wallets,
+		holdings: holdings,
+		tokens:   tokens,
+		swaps:    swaps,
+		bridges:  bridges,
+		chainCosts: func(alchemyAPIKey string) ChainCosts {
+			return blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+		},
+	}
+}
+
+// transferSource is a holding of the user's a transfer can be planned from
+type transferSource struct {
+	holding *models.WalletHolding
+	wallet  *models.Wallet
+}
+
+// Plan returns the ways the user's holdings can deliver the transfer, cheapest first.
+// Holdings of the token on the chain that cover the amount are sent directly; the
+// largest other holdings are swapped or bridged into it first.
+func (p *TransferPlanner) Plan(ctx context.Context, userID uuid.UUID, req TransferPlanRequest, alchemyAPIKey string) (*TransferPlan, error) {
+	recipient, err := address.Parse(req.Recipient)
+	if err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, errors.BadRequest("Amount must be a positive integer in base units")
+	}
+	if req.ChainID <= 0 || req.Token == "" {
+		return nil, errors.BadRequest("ChainID and Token are required")
+	}
+	if req.Slippage == 0 {
+		req.Slippage = defaultEstimateSlippage
+	}
+	if req.Slippage < 0 || req.Slippage > 50 {
+		return nil, errors.BadRequest("Slippage must be between 0 and 50 percent")
+	}
+
+	sources, err := p.sources(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	est := &yieldEstimation{
+		tokens:    p.tokens,
+		swaps:     p.swaps,
+		bridges:   p.bridges,
+		costs:     p.chainCosts(alchemyAPIKey),
+		prices:    make(map[string]*tokenQuote),
+		gasPrices: make(map[int]float64),
+		slippage:  req.Slippage,
+	}
+	plan := &TransferPlan{
+		Recipient: recipient,
+		ChainID:   req.ChainID,
+		Token:     req.Token,
+		Amount:    amount.String(),
+		Options:   []TransferOption{},
+	}
+	amountUSD, priced := est.valueUSD(ctx, req.ChainID, req.Token, plan.Amount)
+	if priced {
+		plan.AmountUSD = &amountUSD
+	}
+	target := quoteTokenAddress(req.Token)
+	sendGas := int64(tokenSendGas)
+	if target == blockchain.NativeTokenAddress {
+		sendGas = nativeSendGas
+	}
+
+	var others []transferSource
+	for _, source := range sources {
+		h := source.holding
+		if h.ChainID == req.ChainID && quoteTokenAddress(h.TokenAddress) == target {
+			if balance, ok := new(big.Int).SetString(h.Balance, 10); ok && balance.Cmp(amount) >= 0 {
+				send := est.send(ctx, req.ChainID, req.Token, plan.Amount, recipient, sendGas)
+				plan.Options = append(plan.Options, transferOption(TransferDirect, source, req.Token, plan.Amount, send))
+			}
+			continue
+		}
+		others = append(others, source)
+	}
+
+	if !priced {
+		est.warn("No USD price is known for the token; only direct sends are planned")
+	} else {
+		sort.SliceStable(others, func(i, j int) bool { return others[i].holding.ValueUSD > others[j].holding.ValueUSD })
+		quoted := 0
+		for _, source := range others {
+			if quoted == maxTransferPlanQuotes || source.holding.ValueUSD < amountUSD {
+				break
+			}
+			if source.holding.ChainID != req.ChainID && !bridgeable(source.wallet) {
+				continue
+			}
+			quoted++
+			if option := p.viaLeg(ctx, est, source, req, plan.Amount, amountUSD, recipient, sendGas); option != nil {
+				plan.Options = append(plan.Options, *option)
+			}
+		}
+	}
+
+	if len(plan.Options) == 0 {
+		if len(est.warnings) > 0 {
+			return nil, errors.BadRequest("No holdings can cover this transfer: " + est.warnings[len(est.warnings)-1])
+		}
+		return nil, errors.BadRequest("No holdings can cover this transfer")
+	}
+	sort.SliceStable(plan.Options, func(i, j int) bool { return plan.Options[i].TotalCostUSD < plan.Options[j].TotalCostUSD })
+	plan.Warnings = est.warnings
+	return plan, nil
+}
+
+// sources lists the user's mainnet EVM holdings with their wallets
+func (p *TransferPlanner) sources(ctx context.Context, userID uuid.UUID) ([]transferSource, error) {
+	wallets, err := p.wallets.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	byID := make(map[uuid.UUID]*models.Wallet)
+	var walletIDs []uuid.UUID
+	for _, wallet := range wallets {
+		if wallet.ChainID > 0 && !wallet.IsTestnet {
+			byID[wallet.ID] = wallet
+			walletIDs = append(walletIDs, wallet.ID)
+		}
+	}
+	if len(walletIDs) == 0 {
+		return nil, errors.BadRequest("Add an EVM wallet to plan transfers from")
+	}
+
+	holdings, err := p.holdings.GetCurrentHoldings(ctx, walletIDs)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	sources := make([]transferSource, 0, len(holdings))
+	for _, h := range holdings {
+		if wallet := byID[h.WalletID]; wallet != nil {
+			sources = append(sources, transferSource{holding: h, wallet: wallet})
+		}
+	}
+	return sources, nil
+}
+
+// bridgeable reports whether funds can be bridged to the wallet's address on another
+// chain. Smart accounts may not exist at the same address there.
+func bridgeable(wallet *models.Wallet) bool {
+	return wallet.AccountType == nil || *wallet.AccountType == models.WalletAccountEOA
+}
+
+// viaLeg plans a swap or bridge of a holding into the token, sized to cover the amount
+// with room for slippage and fees, then a send of the amount
+func (p *TransferPlanner) viaLeg(ctx context.Context, est *yieldEstimation, source transferSource, req TransferPlanRequest, amount string, amountUSD float64, recipient string, sendGas int64) *TransferOption {
+	h := source.holding
+	fromToken := h.TokenAddress
+	if quoteTokenAddress(fromToken) == blockchain.NativeTokenAddress {
+		fromToken = evmNativePlaceholder
+	}
+	input, ok := est.sizeInput(ctx, h.ChainID, fromToken, amountUSD*(1+req.Slippage/100+transferSizingMargin))
+	if !ok {
+		est.warn(fmt.Sprintf("No USD price for %s on chain %d; it isn't planned from", h.Symbol, h.ChainID))
+		return nil
+	}
+	if balance, ok := new(big.Int).SetString(h.Balance, 10); !ok || balance.Cmp(input) < 0 {
+		return nil
+	}
+
+	toToken := req.Token
+	if quoteTokenAddress(toToken) == blockchain.NativeTokenAddress {
+		toToken = evmNativePlaceholder
+	}
+	est.user = source.wallet.Address
+	leg, received, err := est.leg(ctx, "", h.ChainID, fromToken, input.String(), req.ChainID, toToken)
+	if err != nil || leg == nil {
+		logger.Warn("Failed to quote transfer leg", "fromChain", h.ChainID, "toChain", req.ChainID, "error", err)
+		est.warn(fmt.Sprintf("No route from %s on chain %d", h.Symbol, h.ChainID))
+		return nil
+	}
+	if out, ok := new(big.Int).SetString(received, 10); !ok || out.Cmp(mustAmount(amount)) < 0 {
+		est.warn(fmt.Sprintf("The route from %s on chain %d delivers less than the amount", h.Symbol, h.ChainID))
+		return nil
+	}
+
+	kind := TransferSwapThenSend
+	if h.ChainID != req.ChainID {
+		kind = TransferBridgeThenSend
+	}
+	steps := []TransferStep{{
+		Type:       leg.Type,
+		Provider:   leg.Provider,
+		FromChain:  leg.FromChain,
+		ToChain:    leg.ToChain,
+		FromToken:  leg.FromToken,
+		ToToken:    leg.ToToken,
+		FromAmount: leg.FromAmount,
+		ToAmount:   leg.ToAmount,
+		CostUSD:    leg.CostUSD,
+		GasUSD:     leg.GasUSD,
+	}}
+	steps = append(steps, est.send(ctx, req.ChainID, req.Token, amount, recipient, sendGas)...)
+	option := transferOption(kind, source, fromToken, input.String(), steps)
+	return &option
+}
+
+// send is the step sending the amount to the recipient
+func (est *yieldEstimation) send(ctx context.Context, chainID int, token, amount, recipient string, gas int64) []TransferStep {
+	return []TransferStep{{
+		Type:       "send",
+		FromChain:  chainID,
+		ToChain:    chainID,
+		FromToken:  token,
+		ToToken:    token,
+		FromAmount: amount,
+		ToAmount:   amount,
+		To:         recipient,
+		GasUSD:     est.gasUSD(ctx, chainID, gas, ""),
+	}}
+}
+
+// sizeInput is how much of a token is worth valueUSD, in base units
+func (est *yieldEstimation) sizeInput(ctx context.Context, chainID int, token string, valueUSD float64) (*big.Int, bool) {
+	quote := est.token(ctx, chainID, token)
+	if quote == nil || quote.priceUSD == nil || *quote.priceUSD <= 0 {
+		return nil, false
+	}
+	units := decimal.NewFromFloat(valueUSD).DivRound(decimal.NewFromFloat(*quote.priceUSD), int32(quote.decimals))
+	raw, err := amounts.FromUnits(units, quote.decimals)
+	if err != nil || raw.Sign() == 0 {
+		return nil, false
+	}
+	return raw, true
+}
+
+func transferOption(kind string, source transferSource, fromToken, fromAmount string, steps []TransferStep) TransferOption {
+	option := TransferOption{
+		Kind:       kind,
+		Wallet:     source.wallet.Address,
+		FromChain:  source.holding.ChainID,
+		FromToken:  fromToken,
+		FromAmount: fromAmount,
+		Steps:      steps,
+	}
+	for _, step := range steps {
+		option.CostUSD += step.CostUSD
+		option.GasUSD += step.GasUSD
+	}
+	option.TotalCostUSD = option.CostUSD + option.GasUSD
+	return option
+}
+
+// mustAmount parses an amount already validated as a base-unit integer
+func mustAmount(amount string) *big.Int {
+	n, _ := new(big.Int).SetString(amount, 10)
+	return n
+}
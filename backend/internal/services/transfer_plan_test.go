@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const transferRecipient = "0x1111111111111111111111111111111111111111"
+
+type transferWallets []*models.Wallet
+
+func (w transferWallets) GetByUserID(context.Context, uuid.UUID) ([]*models.Wallet, error) {
+	return w, nil
+}
+
+type transferHoldings []*models.WalletHolding
+
+func (h transferHoldings) GetCurrentHoldings(context.Context, []uuid.UUID) ([]*models.WalletHolding, error) {
+	return h, nil
+}
+
+func newTransferFixture(bridges *estimateBridges) *TransferPlanner {
+	usd := 1.0
+	safe := models.WalletAccountSafe
+	polygon := &models.Wallet{ID: uuid.New(), Address: estimateUser, ChainID: 137}
+	mainnet := &models.Wallet{ID: uuid.New(), Address: estimateUser, ChainID: 1}
+	smart := &models.Wallet{ID: uuid.New(), Address: "0x2222222222222222222222222222222222222222", ChainID: 1, AccountType: &safe}
+	testnet := &models.Wallet{ID: uuid.New(), Address: estimateUser, ChainID: 11155111, IsTestnet: true}
+
+	return &TransferPlanner{
+		wallets: transferWallets{polygon, mainnet, smart, testnet},
+		holdings: transferHoldings{
+			{WalletID: polygon.ID, ChainID: 137, TokenAddress: estimatePolygonUSDC, Symbol: "USDC", Balance: "150000000", ValueUSD: 150},
+			{WalletID: mainnet.ID, ChainID: 1, TokenAddress: estimateUSDC, Symbol: "USDC", Balance: "5000000000", ValueUSD: 5000},
+			{WalletID: smart.ID, ChainID: 1, TokenAddress: estimateUSDC, Symbol: "USDC", Balance: "9000000000", ValueUSD: 9000},
+		},
+		tokens: estimateTokens{
+			estimateUSDC:        {Address: estimateUSDC, ChainID: 1, Decimals: 6, PriceUSD: &usd},
+			estimatePolygonUSDC: {Address: estimatePolygonUSDC, ChainID: 137, Decimals: 6, PriceUSD: &usd},
+		},
+		swaps:      estimateSwaps{},
+		bridges:    bridges,
+		chainCosts: func(string) ChainCosts { return estimateChainCosts{} },
+	}
+}
+
+func TestTransferPlannerComparesOptions(t *testing.T) {
+	bridges := &estimateBridges{routes: func(req BridgeRouteRequest) ([]BridgeRoute, error) {
+		return []BridgeRoute{{Provider: "lifi", ToAmount: "101000000", Fees: BridgeFees{GasFee: "1.000000"}}}, nil
+	}}
+	p := newTransferFixture(bridges)
+
+	plan, err := p.Plan(context.Background(), uuid.New(), TransferPlanRequest{
+		Recipient: transferRecipient,
+		ChainID:   137,
+		Token:     estimatePolygonUSDC,
+		Amount:    "100000000",
+	}, "")
+	require.NoError(t, err)
+
+	require.NotNil(t, plan.AmountUSD)
+	assert.InDelta(t, 100, *plan.AmountUSD, 1e-9)
+	// The smart account's USDC isn't bridged: it may not exist on Polygon
+	require.Len(t, bridges.calls, 1)
+	// Sized for the amount plus slippage and the margin
+	assert.Equal(t, "102500000", bridges.calls[0].FromAmount)
+	assert.Equal(t, estimateUser, bridges.calls[0].UserAddress)
+
+	require.Len(t, plan.Options, 2)
+	direct, bridged := plan.Options[0], plan.Options[1]
+	assert.Equal(t, TransferDirect, direct.Kind)
+	require.Len(t, direct.Steps, 1)
+	assert.Equal(t, "send", direct.Steps[0].Type)
+	assert.Equal(t, transferRecipient, direct.Steps[0].To)
+	// 65k gas at 20 gwei and $2000
+	assert.InDelta(t, 2.6, direct.TotalCostUSD, 1e-9)
+
+	assert.Equal(t, TransferBridgeThenSend, bridged.Kind)
+	assert.Equal(t, 1, bridged.FromChain)
+	require.Len(t, bridged.Steps, 2)
+	assert.Equal(t, "bridge", bridged.Steps[0].Type)
+	assert.Equal(t, "100000000", bridged.Steps[1].FromAmount)
+	assert.InDelta(t, 1.5, bridged.CostUSD, 1e-9)
+	assert.InDelta(t, 3.6, bridged.GasUSD, 1e-9)
+	assert.InDelta(t, 5.1, bridged.TotalCostUSD, 1e-9)
+	assert.Empty(t, plan.Warnings)
+}
+
+func TestTransferPlannerSkipsShortRoutes(t *testing.T) {
+	bridges := &estimateBridges{routes: func(req BridgeRouteRequest) ([]BridgeRoute, error) {
+		return []BridgeRoute{{Provider: "lifi", ToAmount: "90000000"}}, nil
+	}}
+	p := newTransferFixture(bridges)
+
+	// More than the Polygon wallet holds, and the route from mainnet delivers too little
+	_, err := p.Plan(context.Background(), uuid.New(), TransferPlanRequest{
+		Recipient: transferRecipient,
+		ChainID:   137,
+		Token:     estimatePolygonUSDC,
+		Amount:    "200000000",
+	}, "")
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.Status)
+	assert.Contains(t, appErr.Message, "delivers less than the amount")
+}
+
+func TestTransferPlannerValidation(t *testing.T) {
+	p := newTransferFixture(&estimateBridges{})
+	valid := TransferPlanRequest{Recipient: transferRecipient, ChainID: 137, Token: estimatePolygonUSDC, Amount: "1000"}
+
+	for name, mutate := range map[string]func(r *TransferPlanRequest){
+		"recipient": func(r *TransferPlanRequest) { r.Recipient = "0x123" },
+		"amount":    func(r *TransferPlanRequest) { r.Amount = "1.5" },
+		"zero":      func(r *TransferPlanRequest) { r.Amount = "0" },
+		"chain":     func(r *TransferPlanRequest) { r.ChainID = 0 },
+		"slippage":  func(r *TransferPlanRequest) { r.Slippage = 60 },
+	} {
+		req := valid
+		mutate(&req)
+		_, err := p.Plan(context.Background(), uuid.New(), req, "")
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr, name)
+		assert.Equal(t, 400, appErr.Status, name)
+	}
+}
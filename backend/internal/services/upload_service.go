@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// purgeBatchSize is how many expired uploads are deleted per query
+const purgeBatchSize = 100
+
+// UploadService keeps exported, generated and imported files in object storage, tracks
+// them in the uploads table and enforces per-kind retention
+type UploadService struct {
+	uploadRepo repos.UploadRepository
+	store      storage.Store
+	// retention is how long uploads of each kind are kept; kinds without one are kept
+	// until deleted
+	retention map[string]time.Duration
+	// urlExpiry is the default validity of signed download URLs
+	urlExpiry time.Duration
+	// quota limits how often users export, if set
+	quota QuotaChecker
+	clock clock.Clock
+}
+
+func NewUploadService(uploadRepo repos.UploadRepository, store storage.Store, retention map[string]time.Duration, urlExpiry time.Duration) *UploadService {
+	return &UploadService{
+		uploadRepo: uploadRepo,
+		store:      store,
+		retention:  retention,
+		urlExpiry:  urlExpiry,
+		clock:      clock.System,
+	}
+}
+
+// SetQuota makes saving exports count against the daily export quota; nil lifts it
+func (s *UploadService) SetQuota(quota QuotaChecker) {
+	s.quota = quota
+}
+
+// Save stores data for the user and records it as an upload of the given kind
+func (s *UploadService) Save(ctx context.Context, userID uuid.UUID, kind, filename, contentType string, data []byte) (*models.Upload, error) {
+	if kind == models.UploadKindExport && s.quota != nil {
+		if err := s.quota.CheckQuota(ctx, userID, models.QuotaExportsPerDay); err != nil {
+			return nil, err
+		}
+	}
+
+	upload := &models.Upload{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Kind:        kind,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+	}
+	upload.StorageKey = fmt.Sprintf("%ss/%s/%s/%s", kind, userID, upload.ID, safeFilename(filename))
+	if retention := s.retention[kind]; retention > 0 {
+		expiresAt := s.clock.Now().Add(retention)
+		upload.ExpiresAt = &expiresAt
+	}
+
+	if err := s.store.Put(ctx, upload.StorageKey, data, contentType); err != nil {
+		logger.Error("Failed to store upload", "error", err, "userID", userID, "kind", kind)
+		return nil, errors.Internal("Failed to store file")
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		logger.Error("Failed to record upload", "error", err, "userID", userID, "kind", kind)
+		if err := s.store.Delete(ctx, upload.StorageKey); err != nil {
+			logger.Warn("Failed to delete unrecorded upload", "error", err, "key", upload.StorageKey)
+		}
+		return nil, errors.Internal("Failed to store file")
+	}
+
+	return upload, nil
+}
+
+// Get returns one of the user's uploads
+func (s *UploadService) Get(ctx context.Context, userID, uploadID uuid.UUID) (*models.Upload, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, userID, uploadID)
+	if err != nil {
+		logger.Error("Failed to get upload", "error", err, "uploadID", uploadID)
+		return nil, errors.Internal("Failed to fetch file")
+	}
+	if upload == nil || s.expired(upload) {
+		return nil, errors.NotFound("File")
+	}
+	return upload, nil
+}
+
+// Read returns an upload's contents
+func (s *UploadService) Read(ctx context.Context, upload *models.Upload) ([]byte, error) {
+	data, err := s.store.Get(ctx, upload.StorageKey)
+	if err == storage.ErrNotFound {
+		return nil, errors.NotFound("File")
+	}
+	if err != nil {
+		logger.Error("Failed to read upload", "error", err, "uploadID", upload.ID, "key", upload.StorageKey)
+		return nil, errors.Internal("Failed to read file")
+	}
+	return data, nil
+}
+
+// Link returns the upload with a signed download URL valid for expiry, or the default
+// validity if expiry is zero. The URL never outlives the upload itself.
+func (s *UploadService) Link(ctx context.Context, upload *models.Upload, expiry time.Duration) (*models.UploadLink, error) {
+	if expiry <= 0 {
+		expiry = s.urlExpiry
+	}
+	if upload.ExpiresAt != nil {
+		if remaining := upload.ExpiresAt.Sub(s.clock.Now()); remaining < expiry {
+			expiry = remaining
+		}
+	}
+
+	url, err := s.store.SignedURL(ctx, upload.StorageKey, expiry)
+	if err != nil {
+		logger.Error("Failed to sign upload URL", "error", err, "uploadID", upload.ID)
+		return nil, errors.Internal("Failed to create download link")
+	}
+	return &models.UploadLink{
+		Upload:       upload,
+		DownloadURL:  url,
+		URLExpiresAt: s.clock.Now().Add(expiry).UTC(),
+	}, nil
+}
+
+// GetLink returns one of the user's uploads with a signed download URL
+func (s *UploadService) GetLink(ctx context.Context, userID, uploadID uuid.UUID) (*models.UploadLink, error) {
+	upload, err := s.Get(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Link(ctx, upload, 0)
+}
+
+// Delete removes an upload's file and record
+func (s *UploadService) Delete(ctx context.Context, upload *models.Upload) error {
+	if err := s.store.Delete(ctx, upload.StorageKey); err != nil {
+		return err
+	}
+	return s.uploadRepo.Delete(ctx, upload.ID)
+}
+
+// PurgeExpired deletes uploads past their retention, returning how many were removed.
+// Uploads that fail to delete are logged and retried on the next run.
+func (s *UploadService) PurgeExpired(ctx context.Context) (int, error) {
+	purged := 0
+	failed := make(map[uuid.UUID]bool)
+	for {
+		uploads, err := s.uploadRepo.GetExpired(ctx, s.clock.Now(), purgeBatchSize+len(failed))
+		if err != nil {
+			return purged, err
+		}
+
+		progressed := false
+		for _, upload := range uploads {
+			if ctx.Err() != nil {
+				return purged, ctx.Err()
+			}
+			if failed[upload.ID] {
+				continue
+			}
+			if err := s.Delete(ctx, upload); err != nil {
+				logger.Error("Failed to purge upload", "error", err, "uploadID", upload.ID, "key", upload.StorageKey)
+				failed[upload.ID] = true
+				continue
+			}
+			purged++
+			progressed = true
+		}
+
+		if !progressed || len(uploads) < purgeBatchSize+len(failed) {
+			return purged, nil
+		}
+	}
+}
+
+func (s *UploadService) expired(upload *models.Upload) bool {
+	return upload.ExpiresAt != nil && !s.clock.Now().Before(*upload.ExpiresAt)
+}
+
+// safeFilename reduces a user-supplied filename to a single path segment of characters
+// that need no escaping in storage keys
+func safeFilename(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name = strings.Trim(b.String(), ".")
+	if name == "" {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeFilename(t *testing.T) {
+	assert.Equal(t, "pnl_export_0xabc.csv", safeFilename("pnl_export_0xabc.csv"))
+	assert.Equal(t, "passwd", safeFilename("../../etc/passwd"))
+	assert.Equal(t, "report.pdf", safeFilename(`C:\Users\me\report.pdf`))
+	assert.Equal(t, "my_statement__1_.pdf", safeFilename("my statement (1).pdf"))
+	assert.Equal(t, "file", safeFilename(".."))
+}
+
+func TestUploadService_LinkNeverOutlivesUpload(t *testing.T) {
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	store := storage.NewLocalStore(t.TempDir(), "http://localhost:3000/api/v1/files", []byte("secret"))
+	s := NewUploadService(nil, store, nil, time.Hour)
+	s.clock = clock.NewFake(now)
+
+	expiresAt := now.Add(10 * time.Minute)
+	upload := &models.Upload{
+		ID:         uuid.New(),
+		Kind:       models.UploadKindExport,
+		StorageKey: "exports/user/id/pnl.csv",
+		ExpiresAt:  &expiresAt,
+	}
+
+	link, err := s.Link(context.Background(), upload, 0)
+	require.NoError(t, err)
+	assert.Equal(t, expiresAt, link.URLExpiresAt)
+	assert.Contains(t, link.DownloadURL, "/api/v1/files/exports/user/id/pnl.csv?")
+
+	upload.ExpiresAt = nil
+	link, err = s.Link(context.Background(), upload, 0)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), link.URLExpiresAt)
+}
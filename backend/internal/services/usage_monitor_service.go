@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// usageAddressWindow is how far back distinct address lookups are counted
+	usageAddressWindow = 10 * time.Minute
+	// usageAddressThreshold is how many distinct addresses a subject can look up within
+	// usageAddressWindow before it's flagged for enumerating them
+	usageAddressThreshold = 100
+	// usageBurstThreshold is how many requests a subject can make in a minute before it's
+	// flagged; the dashboard makes a small fraction of this
+	usageBurstThreshold = 300
+	// usageRestriction is how long a flagged subject is limited to usageRestrictedLimit
+	// requests a minute
+	usageRestriction     = time.Hour
+	usageRestrictedLimit = 20
+	// usageSampleSize is how many of the addresses looked up a flag keeps as evidence
+	usageSampleSize = 10
+	// defaultUsageFlagLimit and maxUsageFlagLimit bound a page of flags
+	defaultUsageFlagLimit = 50
+	maxUsageFlagLimit     = 200
+)
+
+// usageKey names whose usage is tracked: a user, or a provider API key by fingerprint
+type usageKey struct {
+	subjectType string
+	subject     string
+}
+
+// usageActivity is a subject's recent requests and the addresses they looked up, with
+// when each was last seen
+type usageActivity struct {
+	requests  []time.Time
+	addresses map[string]time.Time
+	lastSeen  time.Time
+}
+
+// activeRestriction limits a flagged subject to usageRestrictedLimit requests in each
+// minute until it ends
+type activeRestriction struct {
+	flagID      uuid.UUID
+	until       time.Time
+	windowStart time.Time
+	count       int
+}
+
+// UsageMonitorService watches each user's and provider API key's request pattern for
+// scraping: many distinct addresses enumerated in a short time, or bursts of requests.
+// Subjects caught are flagged for admins and limited more strictly for a while. Activity
+// is tracked in memory per instance; restrictions are shared through the database.
+type UsageMonitorService struct {
+	flagRepo repos.UsageFlagRepository
+	clock    clock.Clock
+
+	mu           sync.Mutex
+	activity     map[usageKey]*usageActivity
+	restrictions map[usageKey]*activeRestriction
+}
+
+func NewUsageMonitorService(flagRepo repos.UsageFlagRepository) *UsageMonitorService {
+	return &UsageMonitorService{
+		flagRepo:     flagRepo,
+		clock:        clock.System,
+		activity:     make(map[usageKey]*usageActivity),
+		restrictions: make(map[usageKey]*activeRestriction),
+	}
+}
+
+// Observe records a request by the user, made with the provider API key if one was
+// sent and looking up the addresses, and returns an error when the user or key is
+// restricted and over its limit
+func (s *UsageMonitorService) Observe(ctx context.Context, userID uuid.UUID, apiKey string, addresses []string) error {
+	keys := []usageKey{{models.UsageSubjectUser, userID.String()}}
+	if apiKey != "" {
+		keys = append(keys, usageKey{models.UsageSubjectAPIKey, apiKeyFingerprint(apiKey)})
+	}
+
+	now := s.clock.Now()
+	var (
+		limited bool
+		flags   []*models.UsageFlag
+	)
+	s.mu.Lock()
+	for _, key := range keys {
+		if restriction := s.restrictions[key]; restriction != nil {
+			if now.Before(restriction.until) {
+				if now.Sub(restriction.windowStart) >= time.Minute {
+					restriction.windowStart, restriction.count = now, 0
+				}
+				restriction.count++
+				if restriction.count > usageRestrictedLimit {
+					limited = true
+				}
+				continue
+			}
+			delete(s.restrictions, key)
+		}
+
+		if flag := s.record(key, now, addresses); flag != nil {
+			flag.UserID = &userID
+			flag.RestrictedUntil = now.Add(usageRestriction)
+			s.restrictions[key] = &activeRestriction{until: flag.RestrictedUntil, windowStart: now, count: 1}
+			delete(s.activity, key)
+			flags = append(flags, flag)
+		}
+	}
+	s.mu.Unlock()
+
+	// The restriction applies at once; the flag is saved for admins after
+	for _, flag := range flags {
+		logger.Warn("API usage flagged", "reason", flag.Reason, "subjectType", flag.SubjectType, "userID", userID, "details", flag.Details)
+		if err := s.flagRepo.Create(ctx, flag); err != nil {
+			logger.Error("Failed to save usage flag", "error", err, "userID", userID)
+			continue
+		}
+		s.mu.Lock()
+		if restriction := s.restrictions[usageKey{flag.SubjectType, flag.Subject}]; restriction != nil {
+			restriction.flagID = flag.ID
+		}
+		s.mu.Unlock()
+	}
+
+	if limited {
+		return errors.New("USAGE_RESTRICTED", "Unusual API usage detected; requests are temporarily limited", 429)
+	}
+	return nil
+}
+
+// record adds a request to the subject's activity and returns a flag if the activity
+// now looks like scraping. It must be called with s.mu held.
+func (s *UsageMonitorService) record(key usageKey, now time.Time, addresses []string) *models.UsageFlag {
+	activity := s.activity[key]
+	if activity == nil {
+		activity = &usageActivity{addresses: make(map[string]time.Time)}
+		s.activity[key] = activity
+	}
+	activity.lastSeen = now
+
+	recent := activity.requests[:0]
+	for _, at := range activity.requests {
+		if now.Sub(at) < time.Minute {
+			recent = append(recent, at)
+		}
+	}
+	activity.requests = append(recent, now)
+	if len(activity.requests) > usageBurstThreshold {
+		return &models.UsageFlag{
+			SubjectType: key.subjectType,
+			Subject:     key.subject,
+			Reason:      models.UsageFlagRequestBurst,
+			Details:     map[string]interface{}{"requests_per_minute": len(activity.requests)},
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil
+	}
+	for _, address := range addresses {
+		activity.addresses[address] = now
+	}
+	for address, at := range activity.addresses {
+		if now.Sub(at) >= usageAddressWindow {
+			delete(activity.addresses, address)
+		}
+	}
+	if len(activity.addresses) > usageAddressThreshold {
+		return &models.UsageFlag{
+			SubjectType: key.subjectType,
+			Subject:     key.subject,
+			Reason:      models.UsageFlagAddressEnumeration,
+			Details: map[string]interface{}{
+				"distinct_addresses": len(activity.addresses),
+				"window_minutes":     int(usageAddressWindow / time.Minute),
+				"sample":             sampleAddresses(activity.addresses),
+			},
+		}
+	}
+	return nil
+}
+
+// Load replaces the restrictions with the flags active in the database, so flags raised
+// by other instances apply here and resolved ones are lifted
+func (s *UsageMonitorService) Load(ctx context.Context) error {
+	now := s.clock.Now()
+	flags, err := s.flagRepo.GetActive(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	restrictions := make(map[usageKey]*activeRestriction, len(flags))
+	for _, flag := range flags {
+		key := usageKey{flag.SubjectType, flag.Subject}
+		if existing := s.restrictions[key]; existing != nil && existing.flagID == flag.ID {
+			restrictions[key] = existing
+			continue
+		}
+		restrictions[key] = &activeRestriction{flagID: flag.ID, until: flag.RestrictedUntil, windowStart: now}
+	}
+	// Keep restrictions whose flags are still being saved
+	for key, restriction := range s.restrictions {
+		if restriction.flagID == uuid.Nil && now.Before(restriction.until) && restrictions[key] == nil {
+			restrictions[key] = restriction
+		}
+	}
+	s.restrictions = restrictions
+
+	for key, activity := range s.activity {
+		if now.Sub(activity.lastSeen) >= usageAddressWindow {
+			delete(s.activity, key)
+		}
+	}
+	return nil
+}
+
+// Watch reloads the restrictions every interval until ctx is done, dropping the activity
+// of subjects gone quiet. A failed reload keeps the restrictions last loaded.
+func (s *UsageMonitorService) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil {
+				logger.Warn("Failed to reload usage restrictions", "error", err)
+			}
+		}
+	}
+}
+
+// ListFlags returns the latest flags, newest first, optionally only those still
+// restricting their subject
+func (s *UsageMonitorService) ListFlags(ctx context.Context, activeOnly bool, limit int) ([]*models.UsageFlag, error) {
+	if limit <= 0 {
+		limit = defaultUsageFlagLimit
+	}
+	if limit > maxUsageFlagLimit {
+		limit = maxUsageFlagLimit
+	}
+	flags, err := s.flagRepo.List(ctx, activeOnly, s.clock.Now(), limit)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return flags, nil
+}
+
+// ResolveFlag lifts a flag's restriction, here at once and on other instances when they
+// next reload
+func (s *UsageMonitorService) ResolveFlag(ctx context.Context, adminID, id uuid.UUID) error {
+	resolved, err := s.flagRepo.Resolve(ctx, id, adminID, s.clock.Now())
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !resolved {
+		return errors.NotFound("Usage flag")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, restriction := range s.restrictions {
+		if restriction.flagID == id {
+			delete(s.restrictions, key)
+		}
+	}
+	return nil
+}
+
+// apiKeyFingerprint identifies an API key in flags without storing the key
+func apiKeyFingerprint(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sampleAddresses returns the most recently looked up addresses
+func sampleAddresses(addresses map[string]time.Time) []string {
+	sample := make([]string, 0, len(addresses))
+	for address := range addresses {
+		sample = append(sample, address)
+	}
+	sort.Slice(sample, func(i, j int) bool {
+		return addresses[sample[i]].After(addresses[sample[j]])
+	})
+	if len(sample) > usageSampleSize {
+		sample = sample[:usageSampleSize]
+	}
+	return sample
+}
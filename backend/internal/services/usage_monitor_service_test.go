@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryUsageFlagRepo struct {
+	flags []*models.UsageFlag
+}
+
+func (r *memoryUsageFlagRepo) Create(ctx context.Context, flag *models.UsageFlag) error {
+	flag.ID = uuid.New()
+	r.flags = append(r.flags, flag)
+	return nil
+}
+
+func (r *memoryUsageFlagRepo) List(ctx context.Context, activeOnly bool, at time.Time, limit int) ([]*models.UsageFlag, error) {
+	if activeOnly {
+		return r.GetActive(ctx, at)
+	}
+	return r.flags, nil
+}
+
+func (r *memoryUsageFlagRepo) GetActive(ctx context.Context, at time.Time) ([]*models.UsageFlag, error) {
+	var active []*models.UsageFlag
+	for _, flag := range r.flags {
+		if flag.ResolvedAt == nil && flag.RestrictedUntil.After(at) {
+			active = append(active, flag)
+		}
+	}
+	return active, nil
+}
+
+func (r *memoryUsageFlagRepo) Resolve(ctx context.Context, id, resolvedBy uuid.UUID, at time.Time) (bool, error) {
+	for _, flag := range r.flags {
+		if flag.ID == id && flag.ResolvedAt == nil {
+			flag.ResolvedAt, flag.ResolvedBy = &at, &resolvedBy
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newTestUsageMonitor(clk clock.Clock) (*UsageMonitorService, *memoryUsageFlagRepo) {
+	repo := &memoryUsageFlagRepo{}
+	service := NewUsageMonitorService(repo)
+	service.clock = clk
+	return service, repo
+}
+
+func testAddress(i int) string {
+	return fmt.Sprintf("0x%040x", i)
+}
+
+func TestUsageMonitorFlagsAddressEnumeration(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	service, repo := newTestUsageMonitor(clk)
+	userID := uuid.New()
+
+	// Looking up the same few addresses over and over is normal dashboard use
+	for i := 0; i < 250; i++ {
+		require.NoError(t, service.Observe(ctx, userID, "", []string{testAddress(i % 5)}))
+		clk.Advance(time.Second)
+	}
+	assert.Empty(t, repo.flags)
+	clk.Advance(usageAddressWindow)
+
+	for i := 0; i <= usageAddressThreshold; i++ {
+		require.NoError(t, service.Observe(ctx, userID, "", []string{testAddress(1000 + i)}))
+		clk.Advance(time.Second)
+	}
+	require.Len(t, repo.flags, 1)
+	flag := repo.flags[0]
+	assert.Equal(t, models.UsageFlagAddressEnumeration, flag.Reason)
+	assert.Equal(t, models.UsageSubjectUser, flag.SubjectType)
+	assert.Equal(t, userID.String(), flag.Subject)
+	assert.Len(t, flag.Details["sample"], usageSampleSize)
+	assert.Equal(t, testAddress(1000+usageAddressThreshold), flag.Details["sample"].([]string)[0], "latest addresses first")
+
+	// Flagged users get a stricter limit
+	var err error
+	for i := 0; i < usageRestrictedLimit && err == nil; i++ {
+		err = service.Observe(ctx, userID, "", nil)
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "temporarily limited")
+
+	clk.Advance(time.Minute)
+	require.NoError(t, service.Observe(ctx, userID, "", nil), "the limit is per minute")
+
+	require.NoError(t, service.ResolveFlag(ctx, uuid.New(), flag.ID))
+	for i := 0; i < usageRestrictedLimit*2; i++ {
+		require.NoError(t, service.Observe(ctx, userID, "", nil), "resolving a flag lifts its limit")
+	}
+	assert.Error(t, service.ResolveFlag(ctx, uuid.New(), flag.ID))
+}
+
+func TestUsageMonitorOldLookupsAgeOut(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	service, repo := newTestUsageMonitor(clk)
+	userID := uuid.New()
+
+	for i := 0; i < usageAddressThreshold*3; i++ {
+		require.NoError(t, service.Observe(ctx, userID, "", []string{testAddress(i)}))
+		clk.Advance(10 * time.Second)
+	}
+	assert.Empty(t, repo.flags, "a few addresses a minute isn't enumeration")
+}
+
+func TestUsageMonitorFlagsBurstsByAPIKey(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	service, repo := newTestUsageMonitor(clk)
+
+	// The same key spread over many accounts is caught as one subject
+	for i := 0; i <= usageBurstThreshold; i++ {
+		require.NoError(t, service.Observe(ctx, uuid.New(), "shared-key", nil))
+	}
+	require.Len(t, repo.flags, 1)
+	assert.Equal(t, models.UsageFlagRequestBurst, repo.flags[0].Reason)
+	assert.Equal(t, models.UsageSubjectAPIKey, repo.flags[0].SubjectType)
+	assert.Equal(t, apiKeyFingerprint("shared-key"), repo.flags[0].Subject)
+	assert.NotContains(t, repo.flags[0].Subject, "shared-key")
+
+	// Another instance picks the restriction up on reload
+	other := NewUsageMonitorService(repo)
+	other.clock = service.clock
+	require.NoError(t, other.Load(ctx))
+	var err error
+	for i := 0; i <= usageRestrictedLimit && err == nil; i++ {
+		err = other.Observe(ctx, uuid.New(), "shared-key", nil)
+	}
+	assert.Error(t, err)
+	require.NoError(t, other.Observe(ctx, uuid.New(), "another-key", nil))
+
+	clk.Advance(usageRestriction)
+	require.NoError(t, other.Observe(ctx, uuid.New(), "shared-key", nil), "restrictions end")
+}
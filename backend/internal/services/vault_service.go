@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+)
+
+// maxExtraVaults caps the vault addresses a request can ask to check besides the listed ones
+const maxExtraVaults = 50
+
+// VaultService reads ERC-4626 vaults and the users' shares in them. Any vault works the
+// same way, so positions are found by checking the wallets against every yield pool
+// listed as a vault on their chain, plus any vault addresses the user names.
+type VaultService struct {
+	walletRepo repos.WalletRepository
+	poolRepo   repos.YieldPoolRepository
+}
+
+func NewVaultService(walletRepo repos.WalletRepository, poolRepo repos.YieldPoolRepository) *VaultService {
+	return &VaultService{
+		walletRepo: walletRepo,
+		poolRepo:   poolRepo,
+	}
+}
+
+// GetVault reads any ERC-4626 vault
+func (s *VaultService) GetVault(ctx context.Context, chainID int, address string, alchemyAPIKey string) (*models.Vault, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.BadRequest("Invalid vault address")
+	}
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+	if !blockchainService.SupportsVaults(chainID) {
+		return nil, errors.BadRequest("Unsupported chain")
+	}
+
+	vault, err := blockchainService.GetVault(ctx, chainID, address)
+	if err != nil {
+		logger.Warn("Failed to read vault", "error", err, "vault", address, "chainID", chainID)
+		return nil, errors.NotFound("ERC-4626 vault")
+	}
+	return vault, nil
+}
+
+// GetPositions returns the user's vault positions across their wallets, largest first.
+// address narrows the result to one wallet, and vaults, a comma-separated address list,
+// are checked on every chain besides the listed vaults.
+func (s *VaultService) GetPositions(ctx context.Context, userID uuid.UUID, address, vaults string, alchemyAPIKey string) ([]*models.VaultPosition, error) {
+	extraVaults := splitParamList(vaults)
+	if len(extraVaults) > maxExtraVaults {
+		return nil, errors.BadRequest("At most 50 vaults can be checked at once")
+	}
+	for _, vault := range extraVaults {
+		if !common.IsHexAddress(vault) {
+			return nil, errors.BadRequest("Invalid vault address " + vault)
+		}
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+	var targets []*models.Wallet
+	for _, wallet := range wallets {
+		if address != "" && !strings.EqualFold(wallet.Address, address) {
+			continue
+		}
+		if blockchainService.SupportsVaults(wallet.ChainID) {
+			targets = append(targets, wallet)
+		}
+	}
+	if address != "" && len(targets) == 0 {
+		return nil, errors.NotFound("Wallet")
+	}
+
+	// Listed vaults per chain, keyed by lowercase address
+	listed := make(map[int]map[string]*models.YieldPool)
+	for _, wallet := range targets {
+		if _, ok := listed[wallet.ChainID]; ok {
+			continue
+		}
+		pools, err := s.poolRepo.GetByChain(ctx, wallet.ChainID)
+		if err != nil {
+			logger.Error("Failed to get yield pools", "error", err, "chainID", wallet.ChainID)
+			return nil, errors.DatabaseError(err)
+		}
+		listed[wallet.ChainID] = vaultPools(pools)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		positions = []*models.VaultPosition{}
+		failed    int
+	)
+	for _, wallet := range targets {
+		addresses := vaultAddresses(listed[wallet.ChainID], extraVaults)
+		if len(addresses) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(wallet *models.Wallet) {
+			defer wg.Done()
+			walletPositions, err := blockchainService.GetVaultPositions(ctx, wallet.Address, wallet.ChainID, addresses)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Error("Failed to read vault positions", "error", err, "address", wallet.Address, "chainID", wallet.ChainID)
+				failed++
+				return
+			}
+			for _, position := range walletPositions {
+				if pool, ok := listed[wallet.ChainID][strings.ToLower(position.Vault)]; ok {
+					position.PoolID = &pool.ID
+					position.APY = pool.APY
+				}
+			}
+			positions = append(positions, walletPositions...)
+		}(wallet)
+	}
+	wg.Wait()
+
+	if failed > 0 && failed == len(targets) {
+		return nil, errors.Internal("Failed to read vault positions")
+	}
+
+	s.valuePositions(ctx, blockchainService, positions)
+	sortByValue(positions)
+	return positions, nil
+}
+
+// valuePositions prices positions by their asset's symbol; positions whose asset has no
+// price keep only their asset amounts
+func (s *VaultService) valuePositions(ctx context.Context, blockchainService *blockchain.BlockchainService, positions []*models.VaultPosition) {
+	if len(positions) == 0 {
+		return
+	}
+	symbols := make([]string, 0, len(positions))
+	for _, position := range positions {
+		symbols = append(symbols, position.AssetSymbol)
+	}
+	prices, err := blockchainService.GetSymbolPricesUSD(ctx, symbols)
+	if err != nil {
+		logger.Warn("Failed to price vault assets", "error", err)
+		return
+	}
+	for _, position := range positions {
+		if price, ok := prices[position.AssetSymbol]; ok {
+			value := position.Assets * price
+			position.ValueUSD = &value
+		}
+	}
+}
+
+// vaultPools returns the pools transactions are built for as ERC-4626 vaults, keyed by
+// lowercase vault address
+func vaultPools(pools []*models.YieldPool) map[string]*models.YieldPool {
+	vaults := make(map[string]*models.YieldPool)
+	for _, pool := range pools {
+		if pool.PoolAddress == nil || !common.IsHexAddress(*pool.PoolAddress) {
+			continue
+		}
+		if positionTxProtocol(pool) == blockchain.PositionProtocolERC4626 {
+			vaults[strings.ToLower(*pool.PoolAddress)] = pool
+		}
+	}
+	return vaults
+}
+
+// vaultAddresses merges the listed vaults with extra ones, without duplicates, in a
+// stable order
+func vaultAddresses(listed map[string]*models.YieldPool, extra []string) []string {
+	seen := make(map[string]bool, len(listed)+len(extra))
+	addresses := make([]string, 0, len(listed)+len(extra))
+	for address := range listed {
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range extra {
+		key := strings.ToLower(address)
+		if !seen[key] {
+			seen[key] = true
+			addresses = append(addresses, key)
+		}
+	}
+	return addresses
+}
+
+// sortByValue orders positions by USD value, unpriced ones last
+func sortByValue(positions []*models.VaultPosition) {
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := positions[i].ValueUSD, positions[j].ValueUSD
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+}
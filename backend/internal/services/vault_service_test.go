@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultPools(t *testing.T) {
+	address := func(s string) *string { return &s }
+	yearn := &models.YieldPool{ID: uuid.New(), PoolAddress: address("0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"), Protocol: &models.Protocol{Slug: "yearn-finance"}}
+	flagged := &models.YieldPool{ID: uuid.New(), PoolAddress: address("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"), Metadata: map[string]interface{}{"erc4626": true}}
+	aave := &models.YieldPool{ID: uuid.New(), PoolAddress: address("0xcccccccccccccccccccccccccccccccccccccccc"), Protocol: &models.Protocol{Slug: "aave-v3"}}
+	noAddress := &models.YieldPool{ID: uuid.New(), Protocol: &models.Protocol{Slug: "yearn-finance"}}
+
+	vaults := vaultPools([]*models.YieldPool{yearn, flagged, aave, noAddress})
+	require.Len(t, vaults, 2)
+	assert.Equal(t, yearn, vaults["0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"])
+	assert.Equal(t, flagged, vaults["0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"])
+}
+
+func TestVaultAddresses(t *testing.T) {
+	listed := map[string]*models.YieldPool{
+		"0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb": {},
+		"0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": {},
+	}
+	extra := []string{"0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB", "0xDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDDD"}
+
+	assert.Equal(t, []string{
+		"0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"0xdddddddddddddddddddddddddddddddddddddddd",
+	}, vaultAddresses(listed, extra))
+	assert.Empty(t, vaultAddresses(nil, nil))
+}
+
+func TestSortByValue(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	positions := []*models.VaultPosition{
+		{Vault: "unpriced"},
+		{Vault: "small", ValueUSD: value(10)},
+		{Vault: "large", ValueUSD: value(500)},
+	}
+
+	sortByValue(positions)
+	assert.Equal(t, "large", positions[0].Vault)
+	assert.Equal(t, "small", positions[1].Vault)
+	assert.Equal(t, "unpriced", positions[2].Vault)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// WalletBackfillService reports how far the import of a wallet's transaction history
+// has got. The worker's wallet backfill job does the importing.
+type WalletBackfillService struct {
+	walletRepo   repos.WalletRepository
+	backfillRepo repos.WalletBackfillRepository
+}
+
+func NewWalletBackfillService(walletRepo repos.WalletRepository, backfillRepo repos.WalletBackfillRepository) *WalletBackfillService {
+	return &WalletBackfillService{
+		walletRepo:   walletRepo,
+		backfillRepo: backfillRepo,
+	}
+}
+
+// GetSyncStatus returns the backfill of one of the user's wallets. A wallet added since
+// the job last ran is reported as pending.
+func (s *WalletBackfillService) GetSyncStatus(ctx context.Context, userID, walletID uuid.UUID) (*models.WalletBackfill, error) {
+	wallet, err := s.walletRepo.GetByID(ctx, walletID)
+	if err == repos.ErrWalletNotFound || (err == nil && wallet.UserID != userID) {
+		return nil, errors.NotFound("Wallet")
+	}
+	if err != nil {
+		logger.Error("Failed to get wallet", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to fetch wallet")
+	}
+	if !wallet.IsEVM() {
+		return nil, errors.BadRequest("Transaction history is only imported for EVM wallets")
+	}
+
+	backfill, err := s.backfillRepo.GetByWalletID(ctx, walletID)
+	if err != nil {
+		logger.Error("Failed to get wallet backfill", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to fetch sync status")
+	}
+	if backfill == nil {
+		backfill = &models.WalletBackfill{
+			WalletID:  wallet.ID,
+			ChainID:   wallet.ChainID,
+			Status:    models.BackfillStatusPending,
+			CreatedAt: wallet.CreatedAt,
+			UpdatedAt: wallet.CreatedAt,
+		}
+	}
+	backfill.Progress = backfill.ImportedPercent()
+	return backfill, nil
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxWalletGroups caps how many groups one user can have
+	maxWalletGroups = 50
+	// maxWalletGroupName is the longest name a group can have, in characters
+	maxWalletGroupName = 100
+	// maxWalletGroupDescription is the longest description a group can have, in characters
+	maxWalletGroupDescription = 500
+)
+
+// WalletGroupService lets users file their wallets into named groups and view each
+// group as a portfolio of its own, with combined balances and PnL
+type WalletGroupService struct {
+	groupRepo        repos.WalletGroupRepository
+	walletRepo       repos.WalletRepository
+	portfolioService *PortfolioService
+	pnlService       pnl.Service
+}
+
+func NewWalletGroupService(groupRepo repos.WalletGroupRepository, walletRepo repos.WalletRepository, portfolioService *PortfolioService, pnlService pnl.Service) *WalletGroupService {
+	return &WalletGroupService{
+		groupRepo:        groupRepo,
+		walletRepo:       walletRepo,
+		portfolioService: portfolioService,
+		pnlService:       pnlService,
+	}
+}
+
+func (s *WalletGroupService) Create(ctx context.Context, userID uuid.UUID, req *models.CreateWalletGroupRequest) (*models.WalletGroup, error) {
+	name, err := validateWalletGroupName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	description, err := validateWalletGroupDescription(req.Description)
+	if err != nil {
+		return nil, err
+	}
+	walletIDs, err := s.ownWalletIDs(ctx, userID, req.WalletIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.groupRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if len(existing) >= maxWalletGroups {
+		return nil, errors.BadRequest("Wallet group limit reached; delete one to create another")
+	}
+
+	group := &models.WalletGroup{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		WalletIDs:   walletIDs,
+	}
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, walletGroupSaveError(err)
+	}
+	return group, nil
+}
+
+// List returns the user's groups by name
+func (s *WalletGroupService) List(ctx context.Context, userID uuid.UUID) ([]*models.WalletGroup, error) {
+	groups, err := s.groupRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return groups, nil
+}
+
+func (s *WalletGroupService) Get(ctx context.Context, userID, id uuid.UUID) (*models.WalletGroup, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if group == nil || group.UserID != userID {
+		return nil, errors.NotFound("Wallet group")
+	}
+	return group, nil
+}
+
+func (s *WalletGroupService) Update(ctx context.Context, userID, id uuid.UUID, req *models.UpdateWalletGroupRequest) (*models.WalletGroup, error) {
+	group, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if group.Name, err = validateWalletGroupName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		if group.Description, err = validateWalletGroupDescription(req.Description); err != nil {
+			return nil, err
+		}
+	}
+	if req.WalletIDs != nil {
+		if group.WalletIDs, err = s.ownWalletIDs(ctx, userID, *req.WalletIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, walletGroupSaveError(err)
+	}
+	return group, nil
+}
+
+// Delete removes the group, leaving its wallets ungrouped
+func (s *WalletGroupService) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	deleted, err := s.groupRepo.Delete(ctx, id, userID)
+	if err != nil {
+		return errors.DatabaseError(err)
+	}
+	if !deleted {
+		return errors.NotFound("Wallet group")
+	}
+	return nil
+}
+
+// GetPortfolio combines the balances of the group's wallets across every chain. Wallets
+// whose balances can't be read are reported with an error and left out of the total.
+func (s *WalletGroupService) GetPortfolio(ctx context.Context, userID, id uuid.UUID, hideSmall, includeTestnets bool, alchemyAPIKey, coinGeckoAPIKey string) (*models.WalletGroupPortfolio, error) {
+	group, wallets, err := s.groupWallets(ctx, userID, id, includeTestnets)
+	if err != nil {
+		return nil, err
+	}
+	// Portfolio reads cover every chain an address is on
+	wallets = uniqueAddressWallets(wallets)
+
+	var (
+		wg     sync.WaitGroup
+		failed int
+	)
+	entries := make([]models.WalletGroupPortfolioEntry, len(wallets))
+	for i, wallet := range wallets {
+		entries[i] = models.WalletGroupPortfolioEntry{WalletID: wallet.ID, Address: wallet.Address, Label: wallet.Label}
+		wg.Add(1)
+		go func(entry *models.WalletGroupPortfolioEntry) {
+			defer wg.Done()
+			portfolio, err := s.portfolioService.GetMultiChainBalances(ctx, entry.Address, hideSmall, includeTestnets, false, alchemyAPIKey, coinGeckoAPIKey)
+			if err != nil {
+				logger.Error("Failed to get group wallet balances", "error", err, "address", entry.Address, "groupID", id)
+				entry.Error = "Failed to read balances"
+				return
+			}
+			entry.TotalValue = portfolio.TotalValue
+			entry.Blocks = portfolio.Blocks
+		}(&entries[i])
+	}
+	wg.Wait()
+
+	for _, entry := range entries {
+		if entry.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 && failed == len(entries) {
+		return nil, errors.Internal("Failed to read wallet balances")
+	}
+
+	result := &models.WalletGroupPortfolio{Group: group, Wallets: entries}
+	result.TotalValue = groupPortfolioShares(entries)
+	return result, nil
+}
+
+// GetPnL combines the PnL of the group's EVM wallets over the period. Wallets without
+// PnL to report are listed with an error and left out of the totals.
+func (s *WalletGroupService) GetPnL(ctx context.Context, userID, id uuid.UUID, from, to time.Time, method pnl.CalculationMethod) (*models.WalletGroupPnL, error) {
+	group, wallets, err := s.groupWallets(ctx, userID, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*models.Wallet
+	for _, wallet := range uniqueAddressWallets(wallets) {
+		if wallet.IsEVM() {
+			targets = append(targets, wallet)
+		}
+	}
+
+	var wg sync.WaitGroup
+	entries := make([]models.WalletGroupPnLEntry, len(targets))
+	for i, wallet := range targets {
+		entries[i] = models.WalletGroupPnLEntry{WalletID: wallet.ID, Address: wallet.Address, Label: wallet.Label}
+		wg.Add(1)
+		go func(entry *models.WalletGroupPnLEntry) {
+			defer wg.Done()
+			calculation, err := s.pnlService.CalculatePnL(ctx, entry.Address, from, to, method, nil)
+			if err != nil {
+				logger.Debug("No PnL for group wallet", "error", err, "address", entry.Address, "groupID", id)
+				entry.Error = "Failed to calculate PnL"
+				return
+			}
+			entry.RealizedPnLUSD = calculation.RealizedPnLUSD
+			entry.UnrealizedPnLUSD = calculation.UnrealizedPnLUSD
+			entry.TotalPnLUSD = calculation.TotalPnLUSD
+		}(&entries[i])
+	}
+	wg.Wait()
+
+	result := &models.WalletGroupPnL{
+		Group:   group,
+		Method:  string(method),
+		From:    from,
+		To:      to,
+		Wallets: entries,
+	}
+	sumGroupPnL(result)
+	return result, nil
+}
+
+// groupWallets returns one of the user's groups with its wallets, leaving out testnet
+// wallets unless includeTestnets is set
+func (s *WalletGroupService) groupWallets(ctx context.Context, userID, id uuid.UUID, includeTestnets bool) (*models.WalletGroup, []*models.Wallet, error) {
+	group, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, nil, errors.Internal("Failed to fetch wallets")
+	}
+
+	var members []*models.Wallet
+	for _, wallet := range wallets {
+		if wallet.GroupID == nil || *wallet.GroupID != group.ID {
+			continue
+		}
+		if wallet.IsTestnet && !includeTestnets {
+			continue
+		}
+		members = append(members, wallet)
+	}
+	return group, members, nil
+}
+
+// ownWalletIDs checks every ID is one of the user's wallets and drops duplicates
+func (s *WalletGroupService) ownWalletIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return []uuid.UUID{}, nil
+	}
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to get wallets", "error", err, "userID", userID)
+		return nil, errors.Internal("Failed to fetch wallets")
+	}
+	owned := make(map[uuid.UUID]bool, len(wallets))
+	for _, wallet := range wallets {
+		owned[wallet.ID] = true
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !owned[id] {
+			return nil, errors.BadRequest("Unknown wallet " + id.String())
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// groupPortfolioShares sets each wallet's share of the group's value and returns the total
+func groupPortfolioShares(entries []models.WalletGroupPortfolioEntry) float64 {
+	total := 0.0
+	for _, entry := range entries {
+		total += entry.TotalValue
+	}
+	if total > 0 {
+		for i := range entries {
+			entries[i].Share = entries[i].TotalValue / total
+		}
+	}
+	return total
+}
+
+// sumGroupPnL totals the PnL of the wallets that have any
+func sumGroupPnL(result *models.WalletGroupPnL) {
+	var realized, unrealized, total decimal.Decimal
+	for _, entry := range result.Wallets {
+		if entry.Error != "" {
+			continue
+		}
+		r, _ := decimal.Parse(entry.RealizedPnLUSD)
+		u, _ := decimal.Parse(entry.UnrealizedPnLUSD)
+		t, _ := decimal.Parse(entry.TotalPnLUSD)
+		realized = realized.Add(r)
+		unrealized = unrealized.Add(u)
+		total = total.Add(t)
+	}
+	result.RealizedPnLUSD = realized.String()
+	result.UnrealizedPnLUSD = unrealized.String()
+	result.TotalPnLUSD = total.String()
+}
+
+func validateWalletGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.BadRequest("Name is required")
+	}
+	if utf8.RuneCountInString(name) > maxWalletGroupName {
+		return "", errors.BadRequest("Name must be at most 100 characters")
+	}
+	return name, nil
+}
+
+// validateWalletGroupDescription trims the description, clearing it when empty
+func validateWalletGroupDescription(description *string) (*string, error) {
+	if description == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*description)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(trimmed) > maxWalletGroupDescription {
+		return nil, errors.BadRequest("Description must be at most 500 characters")
+	}
+	return &trimmed, nil
+}
+
+func walletGroupSaveError(err error) error {
+	if err == repos.ErrWalletGroupNameTaken {
+		return errors.New("WALLET_GROUP_EXISTS", "A wallet group with this name already exists", 409)
+	}
+	return errors.DatabaseError(err)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupPortfolioShares(t *testing.T) {
+	entries := []models.WalletGroupPortfolioEntry{
+		{Address: "0xa", TotalValue: 750},
+		{Address: "0xb", TotalValue: 250},
+		{Address: "0xc", Error: "Failed to read balances"},
+	}
+	assert.Equal(t, 1000.0, groupPortfolioShares(entries))
+	assert.Equal(t, 0.75, entries[0].Share)
+	assert.Equal(t, 0.25, entries[1].Share)
+	assert.Zero(t, entries[2].Share)
+
+	empty := []models.WalletGroupPortfolioEntry{{Address: "0xa"}}
+	assert.Zero(t, groupPortfolioShares(empty))
+	assert.Zero(t, empty[0].Share, "no shares of an empty group")
+}
+
+func TestSumGroupPnL(t *testing.T) {
+	result := &models.WalletGroupPnL{Wallets: []models.WalletGroupPnLEntry{
+		{RealizedPnLUSD: "100.5", UnrealizedPnLUSD: "-20", TotalPnLUSD: "80.5"},
+		{RealizedPnLUSD: "-0.5", UnrealizedPnLUSD: "10", TotalPnLUSD: "9.5"},
+		{Error: "Failed to calculate PnL"},
+	}}
+	sumGroupPnL(result)
+	assert.Equal(t, "100", result.RealizedPnLUSD)
+	assert.Equal(t, "-10", result.UnrealizedPnLUSD)
+	assert.Equal(t, "90", result.TotalPnLUSD)
+}
+
+func TestValidateWalletGroup(t *testing.T) {
+	name, err := validateWalletGroupName("  Long-term ")
+	require.NoError(t, err)
+	assert.Equal(t, "Long-term", name)
+
+	_, err = validateWalletGroupName("   ")
+	assert.Error(t, err)
+	_, err = validateWalletGroupName(strings.Repeat("a", maxWalletGroupName+1))
+	assert.Error(t, err)
+
+	blank := "  "
+	description, err := validateWalletGroupDescription(&blank)
+	require.NoError(t, err)
+	assert.Nil(t, description, "blank descriptions are cleared")
+
+	long := strings.Repeat("a", maxWalletGroupDescription+1)
+	_, err = validateWalletGroupDescription(&long)
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// minLabelInteractions is how many transactions must suggest a label before it's offered,
+// so a single swap doesn't make a wallet a "Uniswap trader"
+const minLabelInteractions = 3
+
+// WalletLabelService offers the labels a wallet's on-chain history suggests, counted
+// while the wallet backfill job imports it, and lets the owner accept or dismiss them.
+type WalletLabelService struct {
+	walletRepo repos.WalletRepository
+	labelRepo  repos.WalletLabelRepository
+}
+
+func NewWalletLabelService(walletRepo repos.WalletRepository, labelRepo repos.WalletLabelRepository) *WalletLabelService {
+	return &WalletLabelService{
+		walletRepo: walletRepo,
+		labelRepo:  labelRepo,
+	}
+}
+
+// GetSuggestions returns the labels suggested for one of the user's wallets and those
+// already accepted
+func (s *WalletLabelService) GetSuggestions(ctx context.Context, userID, walletID uuid.UUID) ([]*models.WalletLabelSuggestion, error) {
+	if err := s.checkOwner(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+
+	labels, err := s.labelRepo.GetByWallet(ctx, walletID, minLabelInteractions)
+	if err != nil {
+		logger.Error("Failed to get wallet labels", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to fetch label suggestions")
+	}
+	return labels, nil
+}
+
+// UpdateSuggestion accepts or dismisses a label suggested for one of the user's wallets
+func (s *WalletLabelService) UpdateSuggestion(ctx context.Context, userID, walletID uuid.UUID, req *models.UpdateWalletLabelSuggestionRequest) (*models.WalletLabelSuggestion, error) {
+	if req.Status != models.WalletLabelAccepted && req.Status != models.WalletLabelDismissed {
+		return nil, errors.BadRequest("Status must be accepted or dismissed")
+	}
+	label := strings.TrimSpace(req.Label)
+	if label == "" {
+		return nil, errors.BadRequest("Label is required")
+	}
+	if err := s.checkOwner(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+
+	suggestion, err := s.labelRepo.SetStatus(ctx, walletID, label, req.Status)
+	if err != nil {
+		logger.Error("Failed to update wallet label", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to update label suggestion")
+	}
+	if suggestion == nil {
+		return nil, errors.NotFound("Label suggestion")
+	}
+	return suggestion, nil
+}
+
+func (s *WalletLabelService) checkOwner(ctx context.Context, userID, walletID uuid.UUID) error {
+	wallet, err := s.walletRepo.GetByID(ctx, walletID)
+	if err == repos.ErrWalletNotFound || (err == nil && wallet.UserID != userID) {
+		return errors.NotFound("Wallet")
+	}
+	if err != nil {
+		logger.Error("Failed to get wallet", "error", err, "walletID", walletID)
+		return errors.Internal("Failed to fetch wallet")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// WalletService manages settings of a user's wallets and their removal
+type WalletService struct {
+	walletRepo  repos.WalletRepository
+	removalRepo repos.WalletRemovalRepository
+}
+
+func NewWalletService(walletRepo repos.WalletRepository, removalRepo repos.WalletRemovalRepository) *WalletService {
+	return &WalletService{walletRepo: walletRepo, removalRepo: removalRepo}
+}
+
+// SetVisibility makes one of the user's wallets private, keeping it out of shared views,
+// or visible again
+func (s *WalletService) SetVisibility(ctx context.Context, userID, walletID uuid.UUID, req *models.UpdateWalletVisibilityRequest) (*models.Wallet, error) {
+	if req.Visibility != models.WalletVisibilityVisible && req.Visibility != models.WalletVisibilityPrivate {
+		return nil, errors.BadRequest("Visibility must be visible or private")
+	}
+
+	wallet, err := s.walletRepo.SetVisibility(ctx, walletID, userID, req.Visibility)
+	if err == repos.ErrWalletNotFound {
+		return nil, errors.NotFound("Wallet")
+	}
+	if err != nil {
+		logger.Error("Failed to set wallet visibility", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to update wallet")
+	}
+	return wallet, nil
+}
+
+// Remove removes one of the user's wallets. It disappears at once; the worker's wallet
+// removal job then clears its data, keeping its transactions, PnL lots and valuations
+// when the retention is keep_history.
+func (s *WalletService) Remove(ctx context.Context, userID, walletID uuid.UUID, retention string) (*models.WalletRemoval, error) {
+	if retention != models.WalletRemovalPurge && retention != models.WalletRemovalKeepHistory {
+		return nil, errors.BadRequest("Retention must be purge or keep_history")
+	}
+
+	removal := &models.WalletRemoval{WalletID: walletID, UserID: userID, Retention: retention}
+	err := s.removalRepo.Create(ctx, removal)
+	if err == repos.ErrWalletNotFound {
+		return nil, errors.NotFound("Wallet")
+	}
+	if err != nil {
+		logger.Error("Failed to remove wallet", "error", err, "walletID", walletID)
+		return nil, errors.Internal("Failed to remove wallet")
+	}
+
+	logger.Info("Queued wallet removal", "removalId", removal.ID, "walletID", walletID, "retention", retention)
+	removal.CurrentStep = removal.NextStep()
+	removal.Progress = removal.DonePercent()
+	return removal, nil
+}
+
+// GetRemoval returns one of the user's wallet removals with its progress
+func (s *WalletService) GetRemoval(ctx context.Context, userID, removalID uuid.UUID) (*models.WalletRemoval, error) {
+	removal, err := s.removalRepo.GetByID(ctx, removalID, userID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	if removal == nil {
+		return nil, errors.NotFound("Wallet removal")
+	}
+	removal.CurrentStep = removal.NextStep()
+	removal.Progress = removal.DonePercent()
+	return removal, nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/netguard"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryWebhookReports struct {
+	repos.WebhookReportRepository
+	reports []*models.WebhookReport
+}
+
+func (r *memoryWebhookReports) Create(_ context.Context, report *models.WebhookReport) error {
+	report.ID = uuid.New()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func (r *memoryWebhookReports) GetByUser(_ context.Context, userID uuid.UUID) ([]*models.WebhookReport, error) {
+	var reports []*models.WebhookReport
+	for _, report := range r.reports {
+		if report.UserID == userID {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+func (r *memoryWebhookReports) GetDue(_ context.Context, now time.Time, _ int) ([]*models.WebhookReport, error) {
+	var due []*models.WebhookReport
+	for _, report := range r.reports {
+		if report.Enabled && !report.NextRunAt.After(now) {
+			copied := *report
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryWebhookReports) SaveRun(_ context.Context, report *models.WebhookReport) error {
+	for i, saved := range r.reports {
+		if saved.ID == report.ID {
+			r.reports[i] = report
+		}
+	}
+	return nil
+}
+
+// reportPositions, reportWallets and reportHoldings serve two wallets' holdings and
+// one active position
+type reportPositions struct {
+	repos.YieldPositionRepository
+}
+
+type reportWallets struct {
+	repos.WalletRepository
+}
+
+type reportHoldings struct {
+	repos.WalletValuationRepository
+}
+
+func (reportPositions) GetUserSummary(_ context.Context, _ uuid.UUID) (*models.PositionSummary, error) {
+	return &models.PositionSummary{TotalValueUSD: 500, TotalPnLUSD: 50, TotalPnLPercentage: 11.11, TotalRewardsUSD: 7, ActivePositions: 1}, nil
+}
+
+func (reportPositions) GetUserPositionsWithPools(_ context.Context, _ uuid.UUID, filters repos.PositionFilters) ([]*models.YieldPosition, error) {
+	value, pnl, apy := 500.0, 50.0, 4.2
+	return []*models.YieldPosition{{
+		ID: uuid.New(), ChainID: 1, CurrentValueUSD: &value, UnrealizedPnLUSD: &pnl,
+		Pool: &models.YieldPool{PoolName: "USDC-WETH", APY: &apy}, Protocol: &models.Protocol{Name: "Uniswap V3"},
+	}}, nil
+}
+
+func (reportWallets) GetByUserID(_ context.Context, _ uuid.UUID) ([]*models.Wallet, error) {
+	return []*models.Wallet{{ID: uuid.New()}, {ID: uuid.New()}}, nil
+}
+
+func (reportHoldings) GetCurrentHoldings(_ context.Context, walletIDs []uuid.UUID) ([]*models.WalletHolding, error) {
+	return []*models.WalletHolding{
+		{WalletID: walletIDs[0], ChainID: 1, TokenAddress: "0xA0b8", Symbol: "USDC", Amount: 100, ValueUSD: 100},
+		{WalletID: walletIDs[1], ChainID: 1, TokenAddress: "0xa0B8", Symbol: "USDC", Amount: 50, ValueUSD: 50},
+		{WalletID: walletIDs[0], ChainID: 1, TokenAddress: "0xeeee", Symbol: "ETH", Amount: 0.1, ValueUSD: 300},
+	}, nil
+}
+
+type verifiedURLs map[string]bool
+
+func (v verifiedURLs) IsVerified(_ context.Context, _ uuid.UUID, url string) (bool, error) {
+	return v[url], nil
+}
+
+type reportFailureMailer struct {
+	sent []*models.WebhookReport
+}
+
+func (m *reportFailureMailer) SendWebhookReportFailedEmail(_ context.Context, _ string, report *models.WebhookReport) error {
+	m.sent = append(m.sent, report)
+	return nil
+}
+
+func newTestWebhookReportService(verified verifiedURLs, mailer WebhookReportMailer) (*WebhookReportService, *memoryWebhookReports) {
+	repo := &memoryWebhookReports{}
+	service := NewWebhookReportService(repo, reportPositions{}, reportWallets{}, reportHoldings{}, &emailUsers{email: "owner@example.com"},
+		verified, netguard.Policy{AllowInsecure: true}, mailer)
+	return service, repo
+}
+
+func TestWebhookReportServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	hook := server.URL + "/hook"
+	service, _ := newTestWebhookReportService(verifiedURLs{hook: true}, nil)
+	now := time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC)
+	service.clock = clock.NewFake(now)
+
+	created, err := service.Create(ctx, userID, &models.CreateWebhookReportRequest{
+		Name: " Daily ", URL: hook, Schedule: "0 9 * * *",
+		Sections: []string{"pnl", "summary", "pnl"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Daily", created.Name)
+	assert.Equal(t, []string{"summary", "pnl"}, created.Sections, "sections are deduplicated in payload order")
+	assert.Equal(t, time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC), created.NextRunAt)
+	assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+	assert.True(t, created.Enabled)
+
+	data, err := json.Marshal(created.WebhookReport)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), created.Secret, "the secret is only shown on creation")
+
+	for _, tt := range []struct {
+		name string
+		req  models.CreateWebhookReportRequest
+	}{
+		{"unverified URL", models.CreateWebhookReportRequest{Name: "A", URL: server.URL + "/other", Schedule: "@daily"}},
+		{"invalid schedule", models.CreateWebhookReportRequest{Name: "A", URL: hook, Schedule: "every day"}},
+		{"too frequent", models.CreateWebhookReportRequest{Name: "A", URL: hook, Schedule: "*/30 * * * *"}},
+		{"too frequent hours", models.CreateWebhookReportRequest{Name: "A", URL: hook, Schedule: "0,15 9 * * *"}},
+		{"unknown section", models.CreateWebhookReportRequest{Name: "A", URL: hook, Schedule: "@daily", Sections: []string{"trades"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(ctx, userID, &tt.req)
+			require.Error(t, err)
+			assert.Equal(t, 400, err.(*errors.AppError).Status)
+		})
+	}
+
+	_, err = service.Create(ctx, userID, &models.CreateWebhookReportRequest{Name: "Hourly", URL: hook, Schedule: "@hourly"})
+	assert.NoError(t, err)
+}
+
+func TestWebhookReportServiceRunDue(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	status := http.StatusOK
+	var body []byte
+	var signature, idempotencyKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature, idempotencyKey = r.Header.Get(webhookReportSignatureHeader), r.Header.Get("Idempotency-Key")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	mailer := &reportFailureMailer{}
+	service, repo := newTestWebhookReportService(verifiedURLs{server.URL: true}, mailer)
+	clk := clock.NewFake(time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC))
+	service.clock = clk
+	created, err := service.Create(ctx, userID, &models.CreateWebhookReportRequest{Name: "Daily", URL: server.URL, Schedule: "0 9 * * *"})
+	require.NoError(t, err)
+
+	delivered, failed, err := service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered+failed, "nothing is due before 9:00")
+
+	clk.Advance(time.Hour)
+	delivered, failed, err = service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, signWebhookReport(created.Secret, clk.Now(), body), signature)
+	assert.Contains(t, signature, "t=1790845200,v1=")
+
+	var payload models.WebhookReportPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, created.ID, payload.ReportID)
+	assert.Equal(t, payload.DeliveryID.String(), idempotencyKey)
+	require.NotNil(t, payload.Summary)
+	assert.InDelta(t, 950, payload.Summary.TotalValueUSD, 1e-9)
+	assert.Equal(t, 2, payload.Summary.Wallets)
+	require.Len(t, payload.Summary.TopHoldings, 2)
+	assert.Equal(t, "ETH", payload.Summary.TopHoldings[0].Symbol)
+	assert.InDelta(t, 150, payload.Summary.TopHoldings[1].ValueUSD, 1e-9, "a token's balances are summed across wallets")
+	require.Len(t, payload.Positions, 1)
+	assert.Equal(t, "Uniswap V3", payload.Positions[0].Protocol)
+	assert.InDelta(t, 50, *payload.Positions[0].PnLUSD, 1e-9)
+	require.NotNil(t, payload.PnL)
+	assert.InDelta(t, 7, payload.PnL.TotalRewardsUSD, 1e-9)
+
+	report := repo.reports[0]
+	assert.Equal(t, models.WebhookReportDelivered, *report.LastStatus)
+	assert.Equal(t, time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC), report.NextRunAt)
+
+	// The owner is emailed once deliveries keep failing, and not again until one goes through
+	status = http.StatusServiceUnavailable
+	for i := 0; i < webhookReportFailureAlertAfter+1; i++ {
+		clk.Advance(24 * time.Hour)
+		_, failed, err = service.RunDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, failed)
+	}
+	report = repo.reports[0]
+	assert.Equal(t, webhookReportFailureAlertAfter+1, report.ConsecutiveFailures)
+	assert.Contains(t, *report.LastError, "status 503")
+	require.Len(t, mailer.sent, 1)
+	assert.Equal(t, webhookReportFailureAlertAfter, mailer.sent[0].ConsecutiveFailures)
+
+	status = http.StatusOK
+	clk.Advance(24 * time.Hour)
+	delivered, _, err = service.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 0, repo.reports[0].ConsecutiveFailures)
+	assert.False(t, repo.reports[0].FailureAlerted)
+}
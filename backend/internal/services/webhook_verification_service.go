@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/defi-dashboard/backend/pkg/netguard"
+	"github.com/google/uuid"
+)
+
+const (
+	// webhookTimeout bounds each request to a webhook, verification or delivery
+	webhookTimeout = 10 * time.Second
+	// maxWebhookResponseBytes is how much of a webhook's response is read
+	maxWebhookResponseBytes = 4096
+	// maxWebhookURLLength bounds the webhook URLs alerts can be given
+	maxWebhookURLLength = 2048
+)
+
+// WebhookVerifier tells whether a user has verified a webhook URL
+type WebhookVerifier interface {
+	IsVerified(ctx context.Context, userID uuid.UUID, url string) (bool, error)
+}
+
+// WebhookVerificationService checks webhook URLs users give alerts and has them answer
+// a challenge before anything is delivered to them, so the server can't be made to post
+// to its own network or to URLs whose owners never asked for it
+type WebhookVerificationService struct {
+	repo   repos.WebhookVerificationRepository
+	policy netguard.Policy
+	client *http.Client
+	// quota limits how many webhooks users verify, if set
+	quota QuotaChecker
+	clock clock.Clock
+}
+
+func NewWebhookVerificationService(repo repos.WebhookVerificationRepository, policy netguard.Policy) *WebhookVerificationService {
+	return &WebhookVerificationService{
+		repo:   repo,
+		policy: policy,
+		client: policy.Client(webhookTimeout),
+		clock:  clock.System,
+	}
+}
+
+// SetQuota makes verifying a new webhook count against the webhook channel quota; nil
+// lifts it
+func (s *WebhookVerificationService) SetQuota(quota QuotaChecker) {
+	s.quota = quota
+}
+
+// Verify posts a challenge to the URL and records the URL as verified for the user if
+// the response echoes it
+func (s *WebhookVerificationService) Verify(ctx context.Context, userID uuid.UUID, rawURL string) (*models.WebhookVerification, error) {
+	url := strings.TrimSpace(rawURL)
+	if err := checkWebhookURL(ctx, s.policy, url); err != nil {
+		return nil, err
+	}
+	if s.quota != nil {
+		// Verifying a webhook again doesn't take another channel
+		verified, err := s.repo.IsVerified(ctx, userID, webhookURLHash(url))
+		if err != nil {
+			return nil, errors.DatabaseError(err)
+		}
+		if !verified {
+			if err := s.quota.CheckQuota(ctx, userID, models.QuotaWebhookChannels); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	challenge, err := newWebhookChallenge()
+	if err != nil {
+		return nil, errors.Internal("Failed to create webhook challenge")
+	}
+	if err := s.challenge(ctx, url, challenge); err != nil {
+		logger.Info("Webhook verification failed", "userID", userID, "error", err)
+		return nil, errors.New("WEBHOOK_VERIFICATION_FAILED", "Webhook did not answer the verification challenge: "+err.Error(), 422)
+	}
+
+	verification := &models.WebhookVerification{URL: url, VerifiedAt: s.clock.Now()}
+	if err := s.repo.MarkVerified(ctx, userID, webhookURLHash(url), verification.VerifiedAt); err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return verification, nil
+}
+
+// IsVerified reports whether the user has verified the URL
+func (s *WebhookVerificationService) IsVerified(ctx context.Context, userID uuid.UUID, url string) (bool, error) {
+	return s.repo.IsVerified(ctx, userID, webhookURLHash(strings.TrimSpace(url)))
+}
+
+// challenge posts the challenge to url and checks the response carries it back
+func (s *WebhookVerificationService) challenge(ctx context.Context, url, challenge string) error {
+	payload, err := json.Marshal(models.WebhookChallenge{Type: models.WebhookChallengeType, Challenge: challenge})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("returned status %d", resp.StatusCode)
+	}
+	body, err := netguard.ReadLimited(resp.Body, maxWebhookResponseBytes)
+	if err != nil {
+		return err
+	}
+
+	answer := strings.TrimSpace(string(body))
+	var echoed struct {
+		Challenge string `json:"challenge"`
+	}
+	if json.Unmarshal(body, &echoed) == nil && echoed.Challenge != "" {
+		answer = echoed.Challenge
+	}
+	if subtle.ConstantTimeCompare([]byte(answer), []byte(challenge)) != 1 {
+		return fmt.Errorf("response did not echo the challenge")
+	}
+	return nil
+}
+
+// checkWebhookURL checks a webhook URL an alert is given is one the server may post to
+func checkWebhookURL(ctx context.Context, policy netguard.Policy, url string) error {
+	if url == "" {
+		return errors.BadRequest("Webhook URL is required")
+	}
+	if len(url) > maxWebhookURLLength {
+		return errors.BadRequest(fmt.Sprintf("Webhook URL must be at most %d characters", maxWebhookURLLength))
+	}
+	if _, err := policy.CheckURL(ctx, url); err != nil {
+		return errors.BadRequest("Invalid webhook URL: " + err.Error())
+	}
+	return nil
+}
+
+// webhookURLHash identifies a webhook URL without storing it, as URLs often carry a
+// secret token
+func webhookURLHash(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func newWebhookChallenge() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
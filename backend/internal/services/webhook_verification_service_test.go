@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/netguard"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryWebhookVerificationRepo struct {
+	verified map[string]bool
+}
+
+func (r *memoryWebhookVerificationRepo) MarkVerified(_ context.Context, userID uuid.UUID, urlHash string, _ time.Time) error {
+	r.verified[userID.String()+urlHash] = true
+	return nil
+}
+
+func (r *memoryWebhookVerificationRepo) IsVerified(_ context.Context, userID uuid.UUID, urlHash string) (bool, error) {
+	return r.verified[userID.String()+urlHash], nil
+}
+
+func echoChallengeServer(t *testing.T, answer func(challenge string) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got models.WebhookChallenge
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		assert.Equal(t, models.WebhookChallengeType, got.Type)
+		_, _ = w.Write([]byte(answer(got.Challenge)))
+	}))
+}
+
+func TestWebhookVerificationService_Verify(t *testing.T) {
+	repo := &memoryWebhookVerificationRepo{verified: map[string]bool{}}
+	service := NewWebhookVerificationService(repo, netguard.Policy{AllowInsecure: true})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	server := echoChallengeServer(t, func(challenge string) string {
+		return `{"challenge":"` + challenge + `"}`
+	})
+	defer server.Close()
+
+	verification, err := service.Verify(ctx, userID, server.URL+"/hook")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/hook", verification.URL)
+
+	verified, err := service.IsVerified(ctx, userID, server.URL+"/hook")
+	require.NoError(t, err)
+	assert.True(t, verified)
+	verified, err = service.IsVerified(ctx, uuid.New(), server.URL+"/hook")
+	require.NoError(t, err)
+	assert.False(t, verified, "verification is per user")
+
+	plain := echoChallengeServer(t, func(challenge string) string { return challenge + "\n" })
+	defer plain.Close()
+	_, err = service.Verify(ctx, userID, plain.URL)
+	assert.NoError(t, err)
+}
+
+func TestWebhookVerificationService_VerifyRejects(t *testing.T) {
+	repo := &memoryWebhookVerificationRepo{verified: map[string]bool{}}
+	userID := uuid.New()
+	ctx := context.Background()
+
+	ignoring := echoChallengeServer(t, func(string) string { return "ok" })
+	defer ignoring.Close()
+	_, err := NewWebhookVerificationService(repo, netguard.Policy{AllowInsecure: true}).Verify(ctx, userID, ignoring.URL)
+	require.Error(t, err)
+	assert.Equal(t, 422, err.(*errors.AppError).Status)
+	verified, _ := repo.IsVerified(ctx, userID, webhookURLHash(ignoring.URL))
+	assert.False(t, verified)
+
+	strict := NewWebhookVerificationService(repo, netguard.Policy{})
+	for _, url := range []string{"", "http://93.184.216.34/hook", "https://127.0.0.1/hook", "https://169.254.169.254/latest"} {
+		_, err := strict.Verify(ctx, userID, url)
+		require.Error(t, err, url)
+		assert.Equal(t, 400, err.(*errors.AppError).Status, url)
+	}
+}
+
+func TestVerifiedWebhookChannelSend(t *testing.T) {
+	delivered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { delivered++ }))
+	defer server.Close()
+
+	repo := &memoryWebhookVerificationRepo{verified: map[string]bool{}}
+	userID := uuid.New()
+	channel := NewVerifiedWebhookChannel(server.Client(), NewWebhookVerificationService(repo, netguard.Policy{AllowInsecure: true}))
+	to := NotificationRecipient{UserID: userID, Address: server.URL}
+
+	assert.Error(t, channel.Send(context.Background(), to, models.AlertNotificationMessage{}))
+	assert.Equal(t, 0, delivered)
+
+	require.NoError(t, repo.MarkVerified(context.Background(), userID, webhookURLHash(server.URL), time.Now()))
+	require.NoError(t, channel.Send(context.Background(), to, models.AlertNotificationMessage{}))
+	assert.Equal(t, 1, delivered)
+}
+
+func TestAlertServiceValidateNotification(t *testing.T) {
+	service := &alertService{}
+	ctx := context.Background()
+
+	notification := models.AlertNotification{Webhook: " https://93.184.216.34/hook ", Discord: "https://discord.com/api/webhooks/1/abc"}
+	require.NoError(t, service.validateNotification(ctx, &notification))
+	assert.Equal(t, "https://93.184.216.34/hook", notification.Webhook)
+
+	for _, notification := range []models.AlertNotification{
+		{Webhook: "http://93.184.216.34/hook"},
+		{Webhook: "https://10.0.0.1/hook"},
+		{Discord: "https://169.254.169.254/api/webhooks/1/abc"},
+	} {
+		err := service.validateNotification(ctx, &notification)
+		require.Error(t, err)
+		assert.Equal(t, 400, err.(*errors.AppError).Status)
+	}
+}
@@ -0,0 +1,473 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// Gas a pool deposit or withdrawal takes; aggregator quotes only cover the swap or
+	// bridge leading to the pool
+	poolDepositGas  = 250_000
+	poolWithdrawGas = 200_000
+
+	// defaultEstimateSlippage is the slippage, in percent, legs are quoted with by default
+	defaultEstimateSlippage = 0.5
+	// maxHoldingPeriods caps how many holding periods one estimate covers
+	maxHoldingPeriods = 10
+	// maxHoldingPeriodDays is the longest holding period an estimate covers, in days
+	maxHoldingPeriodDays = 3650
+)
+
+// defaultHoldingPeriods are the periods, in days, estimates cover unless asked otherwise
+var defaultHoldingPeriods = []int{30, 90, 180, 365}
+
+// Stages of a yield estimate leg
+const (
+	YieldLegEntry = "entry"
+	YieldLegExit  = "exit"
+)
+
+type YieldEstimateRequest struct {
+	FromChain int    `json:"fromChain"`
+	FromToken string `json:"fromToken"`
+	// Amount is the deposit in base units of FromToken
+	Amount      string  `json:"amount"`
+	UserAddress string  `json:"userAddress"`
+	Slippage    float64 `json:"slippage,omitempty"`
+	// HoldingPeriods are the periods, in days, to compute net APY over
+	HoldingPeriods []int `json:"holdingPeriods,omitempty"`
+}
+
+// YieldEstimateLeg is a swap or bridge needed to move funds into or out of a pool
+type YieldEstimateLeg struct {
+	Stage      string `json:"stage"`
+	Type       string `json:"type"`
+	Provider   string `json:"provider"`
+	FromChain  int    `json:"fromChain"`
+	ToChain    int    `json:"toChain"`
+	FromToken  string `json:"fromToken"`
+	ToToken    string `json:"toToken"`
+	FromAmount string `json:"fromAmount"`
+	ToAmount   string `json:"toAmount"`
+	// CostUSD is the value lost between what goes in and what comes out, or the quoted
+	// fees when either side has no price
+	CostUSD float64 `json:"costUsd"`
+	GasUSD  float64 `json:"gasUsd"`
+}
+
+// YieldHoldingPeriod is the outcome of holding a pool position for a number of days
+type YieldHoldingPeriod struct {
+	Days      int     `json:"days"`
+	EarnedUSD float64 `json:"earnedUsd"`
+	// NetReturnUSD is what's earned less entry and exit costs
+	NetReturnUSD float64 `json:"netReturnUsd"`
+	// NetAPY annualizes the net return on the amount deposited
+	NetAPY float64 `json:"netApy"`
+}
+
+type YieldEstimate struct {
+	PoolID       uuid.UUID `json:"poolId"`
+	ChainID      int       `json:"chainId"`
+	DepositToken string    `json:"depositToken"`
+	APY          float64   `json:"apy"`
+	// AmountUSD is the value of the amount deposited; InvestedUSD is what reaches the pool
+	AmountUSD    float64            `json:"amountUsd"`
+	InvestedUSD  float64            `json:"investedUsd"`
+	Legs         []YieldEstimateLeg `json:"legs"`
+	EntryCostUSD float64            `json:"entryCostUsd"`
+	ExitCostUSD  float64            `json:"exitCostUsd"`
+	// ExitEstimated is set when no exit route could be quoted and exit costs mirror entry
+	ExitEstimated bool `json:"exitEstimated"`
+	// BreakEvenDays is how long the position takes to earn back its costs
+	BreakEvenDays *float64             `json:"breakEvenDays,omitempty"`
+	Periods       []YieldHoldingPeriod `json:"periods"`
+	// Warnings lists costs that couldn't be priced and are left out
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type yieldEstimatePools interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.YieldPool, error)
+}
+
+type yieldEstimateTokens interface {
+	GetByAddress(ctx context.Context, address string, chainID int) (*models.Token, error)
+}
+
+type yieldEstimateSwaps interface {
+	GetQuotes(ctx context.Context, req SwapQuoteRequest) ([]SwapRoute, error)
+}
+
+type yieldEstimateBridges interface {
+	GetRoutes(ctx context.Context, req BridgeRouteRequest) ([]BridgeRoute, error)
+}
+
+// ChainCosts prices gas on a chain
+type ChainCosts interface {
+	GetGasPriceGwei(ctx context.Context, chainID int) (float64, error)
+	GetNativePriceUSD(ctx context.Context, chainID int) (float64, error)
+}
+
+// YieldEstimator estimates what a pool deposit earns once the swaps and bridges needed
+// to enter and leave the pool, and the gas for them, are paid for
+type YieldEstimator struct {
+	pools   yieldEstimatePools
+	tokens  yieldEstimateTokens
+	swaps   yieldEstimateSwaps
+	bridges yieldEstimateBridges
+	// chainCosts builds the gas pricer for a request with the caller's Alchemy key
+	chainCosts func(alchemyAPIKey string) ChainCosts
+}
+
+func NewYieldEstimator(pools yieldEstimatePools, tokens yieldEstimateTokens, swaps *SwapService, bridges *BridgeService) *YieldEstimator {
+	return &YieldEstimator{
+		pools:   pools,
+		tokens:  tokens,
+		swaps:   swaps,
+		bridges: bridges,
+		chainCosts: func(alchemyAPIKey string) ChainCosts {
+			return blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+		},
+	}
+}
+
+// Estimate quotes the legs from the source token to the pool's deposit token and back,
+// then works out the net APY of holding the position over each holding period
+func (e *YieldEstimator) Estimate(ctx context.Context, poolID uuid.UUID, req YieldEstimateRequest, alchemyAPIKey string) (*YieldEstimate, error) {
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, errors.BadRequest("Amount must be a positive integer in base units")
+	}
+	if req.FromChain <= 0 || req.FromToken == "" {
+		return nil, errors.BadRequest("FromChain and FromToken are required")
+	}
+	if req.UserAddress == "" {
+		return nil, errors.BadRequest("UserAddress is required")
+	}
+	if req.Slippage == 0 {
+		req.Slippage = defaultEstimateSlippage
+	}
+	if req.Slippage < 0 || req.Slippage > 50 {
+		return nil, errors.BadRequest("Slippage must be between 0 and 50 percent")
+	}
+	periods := req.HoldingPeriods
+	if len(periods) == 0 {
+		periods = defaultHoldingPeriods
+	}
+	if len(periods) > maxHoldingPeriods {
+		return nil, errors.BadRequest(fmt.Sprintf("At most %d holding periods can be estimated at once", maxHoldingPeriods))
+	}
+	for _, days := range periods {
+		if days < 1 || days > maxHoldingPeriodDays {
+			return nil, errors.BadRequest(fmt.Sprintf("Holding periods must be between 1 and %d days", maxHoldingPeriodDays))
+		}
+	}
+
+	pool, err := e.pools.GetByID(ctx, poolID)
+	if err != nil {
+		return nil, repoError(err, "Yield pool")
+	}
+	if pool.ChainID == nil || len(pool.TokenAddresses) == 0 {
+		return nil, errors.BadRequest("Pool has no deposit token to route to")
+	}
+	if pool.APY == nil {
+		return nil, errors.BadRequest("Pool has no APY")
+	}
+
+	est := &yieldEstimation{
+		tokens:    e.tokens,
+		swaps:     e.swaps,
+		bridges:   e.bridges,
+		costs:     e.chainCosts(alchemyAPIKey),
+		prices:    make(map[string]*tokenQuote),
+		gasPrices: make(map[int]float64),
+		slippage:  req.Slippage,
+		user:      req.UserAddress,
+	}
+	poolChain, poolToken := *pool.ChainID, pool.TokenAddresses[0]
+
+	amountUSD, ok := est.valueUSD(ctx, req.FromChain, req.FromToken, req.Amount)
+	if !ok {
+		return nil, errors.BadRequest("No USD price is known for the source token")
+	}
+
+	result := &YieldEstimate{
+		PoolID:       pool.ID,
+		ChainID:      poolChain,
+		DepositToken: poolToken,
+		APY:          *pool.APY,
+		AmountUSD:    amountUSD,
+		Legs:         []YieldEstimateLeg{},
+	}
+
+	entry, invested, err := est.leg(ctx, YieldLegEntry, req.FromChain, req.FromToken, req.Amount, poolChain, poolToken)
+	if err != nil {
+		return nil, err
+	}
+	result.InvestedUSD = amountUSD
+	if entry != nil {
+		result.Legs = append(result.Legs, *entry)
+		result.InvestedUSD = amountUSD - entry.CostUSD
+		result.EntryCostUSD = entry.CostUSD + entry.GasUSD
+	}
+	result.EntryCostUSD += est.gasUSD(ctx, poolChain, poolDepositGas, "")
+
+	exit, _, err := est.leg(ctx, YieldLegExit, poolChain, poolToken, invested, req.FromChain, req.FromToken)
+	switch {
+	case err != nil:
+		// Exits are priced at today's routes anyway; mirror the entry when there's none
+		logger.Warn("Failed to quote yield exit leg", "poolId", pool.ID, "error", err)
+		result.ExitEstimated = true
+		if entry != nil {
+			result.ExitCostUSD = entry.CostUSD + entry.GasUSD
+		}
+	case exit != nil:
+		result.Legs = append(result.Legs, *exit)
+		result.ExitCostUSD = exit.CostUSD + exit.GasUSD
+	}
+	result.ExitCostUSD += est.gasUSD(ctx, poolChain, poolWithdrawGas, "")
+
+	totalCost := result.EntryCostUSD + result.ExitCostUSD
+	for _, days := range periods {
+		result.Periods = append(result.Periods, holdingPeriodReturn(result.AmountUSD, result.InvestedUSD, result.APY, totalCost, days))
+	}
+	result.BreakEvenDays = breakEvenDays(result.InvestedUSD, result.APY, totalCost)
+	result.Warnings = est.warnings
+
+	return result, nil
+}
+
+// tokenQuote is a token's decimals and USD price
+type tokenQuote struct {
+	decimals int
+	priceUSD *float64
+}
+
+// yieldEstimation holds the prices looked up while one estimate is worked out. Transfer
+// plans cost their legs with it too.
+type yieldEstimation struct {
+	tokens    yieldEstimateTokens
+	swaps     yieldEstimateSwaps
+	bridges   yieldEstimateBridges
+	costs     ChainCosts
+	prices    map[string]*tokenQuote
+	gasPrices map[int]float64
+	slippage  float64
+	user      string
+	warnings  []string
+}
+
+// leg quotes the swap or bridge from one token to another, returning the amount that
+// arrives. No leg is needed when the tokens are the same.
+func (est *yieldEstimation) leg(ctx context.Context, stage string, fromChain int, fromToken, amount string, toChain int, toToken string) (*YieldEstimateLeg, string, error) {
+	if fromChain == toChain && strings.EqualFold(fromToken, toToken) {
+		return nil, amount, nil
+	}
+
+	leg := &YieldEstimateLeg{
+		Stage:      stage,
+		FromChain:  fromChain,
+		ToChain:    toChain,
+		FromToken:  fromToken,
+		ToToken:    toToken,
+		FromAmount: amount,
+	}
+	var quotedFeeUSD, quotedGasUSD float64
+	var estimatedGas, gasPrice string
+
+	if fromChain == toChain {
+		routes, err := est.swaps.GetQuotes(ctx, SwapQuoteRequest{
+			ChainID:     fromChain,
+			FromToken:   fromToken,
+			ToToken:     toToken,
+			FromAmount:  amount,
+			UserAddress: est.user,
+			Slippage:    est.slippage,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(routes) == 0 {
+			return nil, "", errors.BadRequest("No swap routes found")
+		}
+		best := routes[0]
+		leg.Type, leg.Provider, leg.ToAmount = "swap", best.Provider, best.ToAmount
+		quotedFeeUSD = parseUSD(best.Fees.ProtocolFee)
+		quotedGasUSD = parseUSD(best.Fees.GasFee)
+		estimatedGas, gasPrice = best.EstimatedGas, best.GasPrice
+	} else {
+		routes, err := est.bridges.GetRoutes(ctx, BridgeRouteRequest{
+			FromChain:   fromChain,
+			ToChain:     toChain,
+			FromToken:   fromToken,
+			ToToken:     toToken,
+			FromAmount:  amount,
+			UserAddress: est.user,
+			Slippage:    est.slippage,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(routes) == 0 {
+			return nil, "", errors.BadRequest("No bridge routes found")
+		}
+		best := routes[0]
+		leg.Type, leg.Provider, leg.ToAmount = "bridge", best.Provider, best.ToAmount
+		quotedFeeUSD = parseUSD(best.Fees.BridgeFee)
+		quotedGasUSD = parseUSD(best.Fees.GasFee)
+		estimatedGas = best.EstimatedGas
+	}
+
+	inUSD, inOK := est.valueUSD(ctx, fromChain, fromToken, amount)
+	outUSD, outOK := est.valueUSD(ctx, toChain, toToken, leg.ToAmount)
+	if inOK && outOK {
+		leg.CostUSD = math.Max(inUSD-outUSD, 0)
+	} else {
+		leg.CostUSD = quotedFeeUSD
+	}
+
+	leg.GasUSD = quotedGasUSD
+	if leg.GasUSD == 0 {
+		if gas, err := strconv.ParseInt(estimatedGas, 10, 64); err == nil && gas > 0 {
+			leg.GasUSD = est.gasUSD(ctx, fromChain, gas, gasPrice)
+		}
+	}
+
+	return leg, leg.ToAmount, nil
+}
+
+// valueUSD values an amount of a token, reporting false when the token has no price
+func (est *yieldEstimation) valueUSD(ctx context.Context, chainID int, token, amount string) (float64, bool) {
+	quote := est.token(ctx, chainID, token)
+	if quote == nil || quote.priceUSD == nil {
+		return 0, false
+	}
+	raw, err := amounts.ParseBaseUnits(amount)
+	if err != nil {
+		return 0, false
+	}
+	value, err := amounts.USDValue(raw, quote.decimals, *quote.priceUSD)
+	if err != nil {
+		return 0, false
+	}
+	return value.Float64(), true
+}
+
+func (est *yieldEstimation) token(ctx context.Context, chainID int, token string) *tokenQuote {
+	key := fmt.Sprintf("%d:%s", chainID, strings.ToLower(token))
+	if quote, ok := est.prices[key]; ok {
+		return quote
+	}
+
+	var quote *tokenQuote
+	if isNativeTokenPlaceholder(token) {
+		if price, err := est.costs.GetNativePriceUSD(ctx, chainID); err == nil {
+			quote = &tokenQuote{decimals: 18, priceUSD: &price}
+		}
+	} else if t, err := est.tokens.GetByAddress(ctx, token, chainID); err == nil && t != nil {
+		quote = &tokenQuote{decimals: t.Decimals, priceUSD: t.PriceUSD}
+	}
+	est.prices[key] = quote
+	return quote
+}
+
+// gasUSD prices an amount of gas on a chain at gasPriceWei, or at the chain's current gas
+// price when that's empty. Gas that can't be priced is left out with a warning.
+func (est *yieldEstimation) gasUSD(ctx context.Context, chainID int, gas int64, gasPriceWei string) float64 {
+	native := est.token(ctx, chainID, evmNativePlaceholder)
+	if native == nil {
+		est.warn(fmt.Sprintf("No gas token price for chain %d; some gas costs are not included", chainID))
+		return 0
+	}
+
+	var feeNative float64
+	if gasPriceWei != "" {
+		fee, err := blockchain.GasFeeNative(gas, gasPriceWei)
+		if err != nil {
+			est.warn(fmt.Sprintf("Invalid gas price quoted on chain %d; some gas costs are not included", chainID))
+			return 0
+		}
+		feeNative = fee
+	} else {
+		gwei, ok := est.gasPrices[chainID]
+		if !ok {
+			var err error
+			if gwei, err = est.costs.GetGasPriceGwei(ctx, chainID); err != nil {
+				est.warn(fmt.Sprintf("No gas price for chain %d; pool deposit and withdrawal gas is not included", chainID))
+				return 0
+			}
+			est.gasPrices[chainID] = gwei
+		}
+		feeNative = float64(gas) * gwei / 1e9
+	}
+	return feeNative * *native.priceUSD
+}
+
+func (est *yieldEstimation) warn(msg string) {
+	for _, w := range est.warnings {
+		if w == msg {
+			return
+		}
+	}
+	est.warnings = append(est.warnings, msg)
+}
+
+// evmNativePlaceholder is the address aggregators use for a chain's gas token
+const evmNativePlaceholder = "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"
+
+func isNativeTokenPlaceholder(token string) bool {
+	return strings.EqualFold(token, evmNativePlaceholder) ||
+		strings.EqualFold(token, "0x0000000000000000000000000000000000000000")
+}
+
+func parseUSD(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// holdingPeriodReturn works out what a position earns over days at a compounding APY
+// and the net APY once costs are paid, annualized on the amount deposited
+func holdingPeriodReturn(amountUSD, investedUSD, apy, costUSD float64, days int) YieldHoldingPeriod {
+	years := float64(days) / 365
+	earned := investedUSD * (math.Pow(1+apy/100, years) - 1)
+	net := earned - costUSD
+
+	netAPY := -100.0
+	if amountUSD > 0 && 1+net/amountUSD > 0 {
+		netAPY = (math.Pow(1+net/amountUSD, 1/years) - 1) * 100
+	}
+	return YieldHoldingPeriod{
+		Days:         days,
+		EarnedUSD:    earned,
+		NetReturnUSD: net,
+		NetAPY:       netAPY,
+	}
+}
+
+// breakEvenDays returns how many days of yield pay for the costs, or nil when the
+// position never earns them back
+func breakEvenDays(investedUSD, apy, costUSD float64) *float64 {
+	if costUSD <= 0 {
+		days := 0.0
+		return &days
+	}
+	if investedUSD <= 0 || apy <= 0 {
+		return nil
+	}
+	days := 365 * math.Log1p(costUSD/investedUSD) / math.Log1p(apy/100)
+	return &days
+}
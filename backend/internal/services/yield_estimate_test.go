@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	estimateUSDC        = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	estimatePolygonUSDC = "0x3c499c542cef5e3811e1192ce70d8cc03d5c3359"
+	estimateUser        = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+)
+
+type estimatePools map[uuid.UUID]*models.YieldPool
+
+func (p estimatePools) GetByID(_ context.Context, id uuid.UUID) (*models.YieldPool, error) {
+	if pool, ok := p[id]; ok {
+		return pool, nil
+	}
+	return nil, repos.ErrYieldPoolNotFound
+}
+
+type estimateTokens map[string]*models.Token
+
+func (t estimateTokens) GetByAddress(_ context.Context, address string, chainID int) (*models.Token, error) {
+	if token, ok := t[strings.ToLower(address)]; ok && token.ChainID == chainID {
+		return token, nil
+	}
+	return nil, errors.NotFound("Token")
+}
+
+type estimateBridges struct {
+	routes func(req BridgeRouteRequest) ([]BridgeRoute, error)
+	calls  []BridgeRouteRequest
+}
+
+func (b *estimateBridges) GetRoutes(_ context.Context, req BridgeRouteRequest) ([]BridgeRoute, error) {
+	b.calls = append(b.calls, req)
+	return b.routes(req)
+}
+
+type estimateSwaps struct{}
+
+func (estimateSwaps) GetQuotes(context.Context, SwapQuoteRequest) ([]SwapRoute, error) {
+	return nil, errors.BadRequest("No swap routes found")
+}
+
+// estimateChainCosts prices gas at 20 gwei and the gas token at $2000 on every chain
+type estimateChainCosts struct{}
+
+func (estimateChainCosts) GetGasPriceGwei(context.Context, int) (float64, error) { return 20, nil }
+func (estimateChainCosts) GetNativePriceUSD(context.Context, int) (float64, error) {
+	return 2000, nil
+}
+
+func newEstimateFixture(bridges *estimateBridges) (*YieldEstimator, uuid.UUID) {
+	usd := 1.0
+	apy := 10.0
+	chainID := 137
+	pool := &models.YieldPool{ID: uuid.New(), ChainID: &chainID, APY: &apy, TokenAddresses: []string{estimatePolygonUSDC}}
+
+	e := &YieldEstimator{
+		pools: estimatePools{pool.ID: pool},
+		tokens: estimateTokens{
+			estimateUSDC:        {Address: estimateUSDC, ChainID: 1, Decimals: 6, PriceUSD: &usd},
+			estimatePolygonUSDC: {Address: estimatePolygonUSDC, ChainID: 137, Decimals: 6, PriceUSD: &usd},
+		},
+		swaps:      estimateSwaps{},
+		bridges:    bridges,
+		chainCosts: func(string) ChainCosts { return estimateChainCosts{} },
+	}
+	return e, pool.ID
+}
+
+func TestYieldEstimatorSameToken(t *testing.T) {
+	bridges := &estimateBridges{}
+	e, poolID := newEstimateFixture(bridges)
+
+	estimate, err := e.Estimate(context.Background(), poolID, YieldEstimateRequest{
+		FromChain:      137,
+		FromToken:      estimatePolygonUSDC,
+		Amount:         "1000000000",
+		UserAddress:    estimateUser,
+		HoldingPeriods: []int{365},
+	}, "")
+	require.NoError(t, err)
+
+	assert.Empty(t, estimate.Legs)
+	assert.Empty(t, bridges.calls)
+	assert.InDelta(t, 1000, estimate.AmountUSD, 1e-9)
+	assert.InDelta(t, 1000, estimate.InvestedUSD, 1e-9)
+	// 250k gas to deposit and 200k to withdraw at 20 gwei and $2000
+	assert.InDelta(t, 10, estimate.EntryCostUSD, 1e-9)
+	assert.InDelta(t, 8, estimate.ExitCostUSD, 1e-9)
+
+	require.Len(t, estimate.Periods, 1)
+	assert.InDelta(t, 100, estimate.Periods[0].EarnedUSD, 1e-9)
+	assert.InDelta(t, 82, estimate.Periods[0].NetReturnUSD, 1e-9)
+	assert.InDelta(t, 8.2, estimate.Periods[0].NetAPY, 1e-9)
+	require.NotNil(t, estimate.BreakEvenDays)
+	assert.InDelta(t, 365*math.Log(1.018)/math.Log(1.1), *estimate.BreakEvenDays, 1e-9)
+	assert.Empty(t, estimate.Warnings)
+}
+
+func TestYieldEstimatorBridgeLegs(t *testing.T) {
+	bridges := &estimateBridges{routes: func(req BridgeRouteRequest) ([]BridgeRoute, error) {
+		if req.FromChain == 137 {
+			return nil, errors.BadRequest("No bridge routes found")
+		}
+		return []BridgeRoute{{
+			Provider:     "lifi",
+			ToAmount:     "995000000",
+			EstimatedGas: "300000",
+			Fees:         BridgeFees{BridgeFee: "4.000000", GasFee: "2.500000"},
+		}}, nil
+	}}
+	e, poolID := newEstimateFixture(bridges)
+
+	estimate, err := e.Estimate(context.Background(), poolID, YieldEstimateRequest{
+		FromChain:   1,
+		FromToken:   estimateUSDC,
+		Amount:      "1000000000",
+		UserAddress: estimateUser,
+	}, "")
+	require.NoError(t, err)
+
+	require.Len(t, bridges.calls, 2)
+	assert.Equal(t, defaultEstimateSlippage, bridges.calls[0].Slippage)
+	// The exit is quoted for what arrives in the pool, back to the source token
+	assert.Equal(t, BridgeRouteRequest{
+		FromChain: 137, ToChain: 1, FromToken: estimatePolygonUSDC, ToToken: estimateUSDC,
+		FromAmount: "995000000", UserAddress: estimateUser, Slippage: defaultEstimateSlippage,
+	}, bridges.calls[1])
+
+	require.Len(t, estimate.Legs, 1)
+	leg := estimate.Legs[0]
+	assert.Equal(t, YieldLegEntry, leg.Stage)
+	assert.Equal(t, "bridge", leg.Type)
+	// Both sides are priced, so the cost is the value lost rather than the quoted fee
+	assert.InDelta(t, 5, leg.CostUSD, 1e-9)
+	assert.InDelta(t, 2.5, leg.GasUSD, 1e-9)
+	assert.InDelta(t, 995, estimate.InvestedUSD, 1e-9)
+	assert.InDelta(t, 17.5, estimate.EntryCostUSD, 1e-9)
+
+	// Without an exit route the exit mirrors the entry
+	assert.True(t, estimate.ExitEstimated)
+	assert.InDelta(t, 15.5, estimate.ExitCostUSD, 1e-9)
+
+	require.Len(t, estimate.Periods, len(defaultHoldingPeriods))
+	short, year := estimate.Periods[0], estimate.Periods[3]
+	assert.Equal(t, 30, short.Days)
+	assert.Less(t, short.NetAPY, year.NetAPY, "costs weigh more on short holds")
+	assert.Less(t, year.NetAPY, 10.0)
+}
+
+func TestYieldEstimatorValidation(t *testing.T) {
+	e, poolID := newEstimateFixture(&estimateBridges{})
+	valid := YieldEstimateRequest{FromChain: 137, FromToken: estimatePolygonUSDC, Amount: "1000", UserAddress: estimateUser}
+
+	for name, mutate := range map[string]func(r *YieldEstimateRequest){
+		"amount":   func(r *YieldEstimateRequest) { r.Amount = "1.5" },
+		"zero":     func(r *YieldEstimateRequest) { r.Amount = "0" },
+		"chain":    func(r *YieldEstimateRequest) { r.FromChain = 0 },
+		"user":     func(r *YieldEstimateRequest) { r.UserAddress = "" },
+		"slippage": func(r *YieldEstimateRequest) { r.Slippage = 60 },
+		"period":   func(r *YieldEstimateRequest) { r.HoldingPeriods = []int{30, 0} },
+		"unpriced": func(r *YieldEstimateRequest) { r.FromToken = "0x0000000000000000000000000000000000000001" },
+	} {
+		req := valid
+		mutate(&req)
+		_, err := e.Estimate(context.Background(), poolID, req, "")
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr, name)
+		assert.Equal(t, 400, appErr.Status, name)
+	}
+
+	_, err := e.Estimate(context.Background(), uuid.New(), valid, "")
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 404, appErr.Status)
+}
+
+func TestHoldingPeriodReturn(t *testing.T) {
+	// Costs larger than the deposit can't be annualized into anything but a total loss
+	period := holdingPeriodReturn(100, 100, 5, 150, 30)
+	assert.Equal(t, -100.0, period.NetAPY)
+
+	assert.Nil(t, breakEvenDays(1000, 0, 10))
+	assert.Equal(t, 0.0, *breakEvenDays(1000, 5, 0))
+}
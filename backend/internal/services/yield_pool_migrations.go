@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// yieldPoolNoticeBatchSize caps the holders told of migrations per run
+const yieldPoolNoticeBatchSize = 200
+
+// maxYieldPoolMigrations caps how many migrations are followed to a pool's successor
+const maxYieldPoolMigrations = 32
+
+// PoolMigrationMailer emails a position holder that their pool was migrated
+type PoolMigrationMailer interface {
+	SendPoolMigrationEmail(ctx context.Context, to string, notice *models.YieldPoolMigrationNotice, successorURL string) error
+}
+
+func (LogEmailSender) SendPoolMigrationEmail(ctx context.Context, to string, notice *models.YieldPoolMigrationNotice, successorURL string) error {
+	logger.Info("Pool migration email", "to", to, "positionID", notice.PositionID, "pool", notice.PoolID, "successor", notice.SuccessorPoolID, "url", successorURL)
+	return nil
+}
+
+// YieldPoolMigrationService tracks the pools protocols migrated away from, such as v2
+// pools replaced by v3 ones. Positions opened in a successor carry on the wallet's
+// position in the old pool, and holders of positions in a deprecated pool are told once
+// where it went.
+type YieldPoolMigrationService struct {
+	poolRepo      repos.YieldPoolRepository
+	migrationRepo repos.YieldPoolMigrationRepository
+	mailer        PoolMigrationMailer
+	publicURL     string
+	clock         clock.Clock
+}
+
+func NewYieldPoolMigrationService(poolRepo repos.YieldPoolRepository, migrationRepo repos.YieldPoolMigrationRepository, mailer PoolMigrationMailer, publicURL string) *YieldPoolMigrationService {
+	if mailer == nil {
+		mailer = LogEmailSender{}
+	}
+	return &YieldPoolMigrationService{
+		poolRepo:      poolRepo,
+		migrationRepo: migrationRepo,
+		mailer:        mailer,
+		publicURL:     strings.TrimRight(publicURL, "/"),
+		clock:         clock.System,
+	}
+}
+
+// SetSuccessor deprecates a pool in favour of the pool it migrated to
+func (s *YieldPoolMigrationService) SetSuccessor(ctx context.Context, poolID string, req *models.SetYieldPoolSuccessorRequest) (*models.YieldPool, error) {
+	if req.SuccessorPoolID == "" {
+		return nil, errors.BadRequest("successor_pool_id is required")
+	}
+
+	switch err := s.migrationRepo.SetSuccessor(ctx, poolID, req.SuccessorPoolID, s.clock.Now()); err {
+	case nil:
+	case repos.ErrYieldPoolNotFound:
+		return nil, errors.NotFound("Yield pool")
+	case repos.ErrYieldPoolMigrationCycle:
+		return nil, errors.BadRequest("A pool can't migrate to itself or to a pool that migrated from it")
+	default:
+		return nil, errors.DatabaseError(err)
+	}
+
+	logger.Info("Yield pool deprecated", "pool", poolID, "successor", req.SuccessorPoolID)
+	return s.getPoolByPoolID(ctx, poolID)
+}
+
+// ClearSuccessor undoes a pool's deprecation
+func (s *YieldPoolMigrationService) ClearSuccessor(ctx context.Context, poolID string) error {
+	switch err := s.migrationRepo.ClearSuccessor(ctx, poolID); err {
+	case nil:
+		return nil
+	case repos.ErrYieldPoolNotFound:
+		return errors.NotFound("Yield pool")
+	default:
+		return errors.DatabaseError(err)
+	}
+}
+
+// GetPool returns a pool and, when it's deprecated, the pool at the end of its
+// migrations as its successor
+func (s *YieldPoolMigrationService) GetPool(ctx context.Context, id uuid.UUID) (*models.YieldPool, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, repoError(err, "Yield pool")
+	}
+
+	successorID := pool.SuccessorPoolID
+	for i := 0; successorID != nil && i < maxYieldPoolMigrations; i++ {
+		successor, err := s.poolRepo.GetByPoolID(ctx, *successorID)
+		if err != nil {
+			// The mapping is kept by a foreign key, so a missing successor is a read failure
+			return nil, errors.DatabaseError(err)
+		}
+		pool.Successor = successor
+		successorID = successor.SuccessorPoolID
+	}
+	return pool, nil
+}
+
+// CarryOver links a position just opened in a successor pool to the one its wallet
+// held in the deprecated pool, returning that position's ID when there was one
+func (s *YieldPoolMigrationService) CarryOver(ctx context.Context, positionID uuid.UUID) (*uuid.UUID, error) {
+	return s.migrationRepo.CarryOver(ctx, positionID)
+}
+
+// NotifyHolders tells the owners of active positions in deprecated pools where their
+// pool went, by email when they have an address, returning how many were told. Each
+// position's owner is told once per migration; a failed email is retried next run.
+func (s *YieldPoolMigrationService) NotifyHolders(ctx context.Context) (int, error) {
+	notices, err := s.migrationRepo.GetPendingNotices(ctx, yieldPoolNoticeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, notice := range notices {
+		emailed := false
+		if notice.Email != nil && *notice.Email != "" {
+			if err := s.mailer.SendPoolMigrationEmail(ctx, *notice.Email, notice, s.successorURL(notice)); err != nil {
+				logger.Warn("Failed to email pool migration", "positionID", notice.PositionID, "pool", notice.PoolID, "error", err)
+				continue
+			}
+			emailed = true
+		}
+		if err := s.migrationRepo.RecordNotice(ctx, notice, emailed, s.clock.Now()); err != nil {
+			return notified, err
+		}
+		notified++
+	}
+	return notified, nil
+}
+
+// successorURL links to the successor pool in the API
+func (s *YieldPoolMigrationService) successorURL(notice *models.YieldPoolMigrationNotice) string {
+	return s.publicURL + "/api/v1/yield/pools/" + notice.SuccessorID.String()
+}
+
+func (s *YieldPoolMigrationService) getPoolByPoolID(ctx context.Context, poolID string) (*models.YieldPool, error) {
+	pool, err := s.poolRepo.GetByPoolID(ctx, poolID)
+	if err != nil {
+		return nil, errors.DatabaseError(err)
+	}
+	return pool, nil
+}
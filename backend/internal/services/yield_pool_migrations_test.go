@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migratedPools keeps pools in memory by their ID and their source's pool ID
+type migratedPools struct {
+	repos.YieldPoolRepository
+	pools []*models.YieldPool
+}
+
+func (r *migratedPools) GetByID(_ context.Context, id uuid.UUID) (*models.YieldPool, error) {
+	for _, p := range r.pools {
+		if p.ID == id {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, repos.ErrYieldPoolNotFound
+}
+
+func (r *migratedPools) GetByPoolID(_ context.Context, poolID string) (*models.YieldPool, error) {
+	for _, p := range r.pools {
+		if p.PoolID == poolID {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, repos.ErrYieldPoolNotFound
+}
+
+// pendingNotices lists the notices not recorded yet
+type pendingNotices struct {
+	repos.YieldPoolMigrationRepository
+	notices  []*models.YieldPoolMigrationNotice
+	recorded map[uuid.UUID]bool
+}
+
+func (r *pendingNotices) GetPendingNotices(_ context.Context, _ int) ([]*models.YieldPoolMigrationNotice, error) {
+	var pending []*models.YieldPoolMigrationNotice
+	for _, n := range r.notices {
+		if _, ok := r.recorded[n.PositionID]; !ok {
+			pending = append(pending, n)
+		}
+	}
+	return pending, nil
+}
+
+func (r *pendingNotices) RecordNotice(_ context.Context, notice *models.YieldPoolMigrationNotice, emailed bool, _ time.Time) error {
+	r.recorded[notice.PositionID] = emailed
+	return nil
+}
+
+// migrationMailer records the emails sent, failing those to failing
+type migrationMailer struct {
+	sent    map[string]string
+	failing string
+}
+
+func (m *migrationMailer) SendPoolMigrationEmail(_ context.Context, to string, _ *models.YieldPoolMigrationNotice, successorURL string) error {
+	if to == m.failing {
+		return fmt.Errorf("smtp unavailable")
+	}
+	m.sent[to] = successorURL
+	return nil
+}
+
+func TestYieldPoolMigrationServiceNotifiesHoldersOnce(t *testing.T) {
+	emailed, failing := "holder@example.com", "bounce@example.com"
+	successorID := uuid.New()
+	notice := func(email *string) *models.YieldPoolMigrationNotice {
+		return &models.YieldPoolMigrationNotice{PositionID: uuid.New(), UserID: uuid.New(), Email: email,
+			PoolID: "pool-v2", SuccessorID: successorID, SuccessorPoolID: "pool-v3"}
+	}
+	withEmail, withoutEmail, withFailing := notice(&emailed), notice(nil), notice(&failing)
+	repo := &pendingNotices{
+		notices:  []*models.YieldPoolMigrationNotice{withEmail, withoutEmail, withFailing},
+		recorded: map[uuid.UUID]bool{},
+	}
+	mailer := &migrationMailer{sent: map[string]string{}, failing: failing}
+	service := NewYieldPoolMigrationService(&migratedPools{}, repo, mailer, "https://api.example.com/")
+	ctx := context.Background()
+
+	notified, err := service.NotifyHolders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, notified)
+	assert.Equal(t, map[string]string{emailed: "https://api.example.com/api/v1/yield/pools/" + successorID.String()}, mailer.sent)
+	assert.Equal(t, map[uuid.UUID]bool{withEmail.PositionID: true, withoutEmail.PositionID: false}, repo.recorded)
+
+	// Only the failed email is tried again
+	mailer.failing = ""
+	notified, err = service.NotifyHolders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, notified)
+	assert.True(t, repo.recorded[withFailing.PositionID])
+}
+
+func TestYieldPoolMigrationServiceGetPoolFollowsMigrations(t *testing.T) {
+	v2, v3 := "pool-v2", "pool-v3"
+	pools := &migratedPools{pools: []*models.YieldPool{
+		{ID: uuid.New(), PoolID: "pool-v1", SuccessorPoolID: &v2},
+		{ID: uuid.New(), PoolID: v2, SuccessorPoolID: &v3},
+		{ID: uuid.New(), PoolID: v3},
+	}}
+	service := NewYieldPoolMigrationService(pools, &pendingNotices{}, nil, "")
+
+	pool, err := service.GetPool(context.Background(), pools.pools[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, pool.Successor)
+	assert.Equal(t, v3, pool.Successor.PoolID, "the successor is the pool at the end of the migrations")
+
+	pool, err = service.GetPool(context.Background(), pools.pools[2].ID)
+	require.NoError(t, err)
+	assert.Nil(t, pool.Successor)
+
+	_, err = service.GetPool(context.Background(), uuid.New())
+	assert.Error(t, err)
+}
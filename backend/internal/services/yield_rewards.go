@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// GetPoolRewards breaks the pool's reward APY down by reward token. Aave v3 reserves are
+// read on-chain for emission rates and end dates; other pools return what the pool sync
+// stored from DefiLlama.
+func (s *YieldService) GetPoolRewards(ctx context.Context, poolID uuid.UUID, alchemyAPIKey string) (*models.PoolRewards, error) {
+	pool, err := s.poolRepo.GetByID(ctx, poolID)
+	if err != nil {
+		return nil, repoError(err, "Yield pool")
+	}
+
+	rewards := pool.RewardAPRs()
+	if onchain, ok := onchainRewards(ctx, pool, alchemyAPIKey); ok {
+		rewards = onchain
+	}
+	return poolRewards(pool, rewards), nil
+}
+
+// onchainRewards reads the emissions of an Aave v3 reserve, reporting false for other
+// pools and when the chain can't be read
+func onchainRewards(ctx context.Context, pool *models.YieldPool, alchemyAPIKey string) ([]models.RewardAPR, bool) {
+	if pool.Protocol == nil || pool.Protocol.Slug != blockchain.PositionProtocolAaveV3 ||
+		pool.ChainID == nil || !blockchain.SupportsAaveV3Rewards(*pool.ChainID) || len(pool.TokenAddresses) == 0 {
+		return nil, false
+	}
+
+	blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+	rewards, err := blockchainService.GetAaveV3RewardEmissions(ctx, *pool.ChainID, pool.TokenAddresses[0])
+	if err != nil {
+		logger.Warn("Failed to read reward emissions", "error", err, "poolID", pool.ID)
+		return nil, false
+	}
+	return rewards, true
+}
+
+func poolRewards(pool *models.YieldPool, rewards []models.RewardAPR) *models.PoolRewards {
+	result := &models.PoolRewards{
+		PoolID:    pool.ID,
+		APY:       pool.APY,
+		APYBase:   pool.APYBase,
+		APYReward: pool.APYReward,
+		Rewards:   rewards,
+	}
+	if result.Rewards == nil {
+		result.Rewards = []models.RewardAPR{}
+	}
+	if pool.APY != nil && *pool.APY > 0 && pool.APYReward != nil {
+		share := *pool.APYReward / *pool.APY * 100
+		result.EmissionShare = &share
+	}
+	for _, reward := range rewards {
+		if reward.EndsAt != nil && (result.EmissionsEndAt == nil || reward.EndsAt.Before(*result.EmissionsEndAt)) {
+			result.EmissionsEndAt = reward.EndsAt
+		}
+	}
+	return result
+}
+
+// rewardComposition splits pending rewards by token, with each token's share of the
+// priced total
+func rewardComposition(pending []models.RewardInfo) []models.RewardShare {
+	var shares []models.RewardShare
+	index := make(map[uuid.UUID]int)
+	total := 0.0
+	for _, reward := range pending {
+		i, seen := index[reward.TokenID]
+		if !seen {
+			share := models.RewardShare{TokenID: reward.TokenID}
+			if reward.Token != nil {
+				share.Symbol = reward.Token.Symbol
+			}
+			i = len(shares)
+			index[reward.TokenID] = i
+			shares = append(shares, share)
+		}
+		if reward.AmountUSD == nil {
+			continue
+		}
+		if shares[i].AmountUSD == nil {
+			shares[i].AmountUSD = new(float64)
+		}
+		*shares[i].AmountUSD += *reward.AmountUSD
+		total += *reward.AmountUSD
+	}
+
+	if total > 0 {
+		for i := range shares {
+			if shares[i].AmountUSD != nil {
+				share := *shares[i].AmountUSD / total * 100
+				shares[i].Share = &share
+			}
+		}
+	}
+	return shares
+}
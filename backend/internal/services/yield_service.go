@@ -4,11 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/clock"
 	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/google/uuid"
 )
 
@@ -16,18 +20,43 @@ type YieldService struct {
 	poolRepo     repos.YieldPoolRepository
 	positionRepo repos.YieldPositionRepository
 	protocolRepo repos.ProtocolRepository
+	tvlRepo      repos.ProtocolTVLRepository
 	userRepo     repos.UserRepository
+	alertService AlertService
+	// migrations carries new positions on from those in the pools they replaced
+	migrations *YieldPoolMigrationService
+	// uow commits operations writing more than once all together
+	uow   repos.UnitOfWork
+	clock clock.Clock
 }
 
-func NewYieldService(poolRepo repos.YieldPoolRepository, positionRepo repos.YieldPositionRepository, protocolRepo repos.ProtocolRepository, userRepo repos.UserRepository) *YieldService {
+// NewYieldService creates the yield service. alertService creates APY alerts requested
+// with new positions and may be nil.
+func NewYieldService(poolRepo repos.YieldPoolRepository, positionRepo repos.YieldPositionRepository, protocolRepo repos.ProtocolRepository, tvlRepo repos.ProtocolTVLRepository, userRepo repos.UserRepository, alertService AlertService) *YieldService {
 	return &YieldService{
 		poolRepo:     poolRepo,
 		positionRepo: positionRepo,
 		protocolRepo: protocolRepo,
+		tvlRepo:      tvlRepo,
 		userRepo:     userRepo,
+		alertService: alertService,
+		uow:          repos.NoUnitOfWork,
+		clock:        clock.System,
 	}
 }
 
+// SetUnitOfWork makes operations writing more than once, like opening a position with
+// its alert, commit all their writes or none
+func (s *YieldService) SetUnitOfWork(uow repos.UnitOfWork) {
+	s.uow = uow
+}
+
+// SetMigrations makes positions opened in a pool that replaced another carry on the
+// wallet's position in the old pool
+func (s *YieldService) SetMigrations(migrations *YieldPoolMigrationService) {
+	s.migrations = migrations
+}
+
 // Pool Management
 
 func (s *YieldService) GetPools(ctx context.Context, filters repos.YieldPoolFilters) ([]*models.YieldPool, int64, error) {
@@ -44,10 +73,134 @@ func (s *YieldService) GetPools(ctx context.Context, filters repos.YieldPoolFilt
 	return pools, count, nil
 }
 
+// yieldRiskLevels are the risk levels pools are rated with
+var yieldRiskLevels = map[string]bool{"low": true, "medium": true, "high": true}
+
+// SearchPools finds pools by text and any combination of filters, 20 per page by default
+// and at most 100
+func (s *YieldService) SearchPools(ctx context.Context, search repos.YieldPoolSearch) ([]*models.YieldPool, int64, error) {
+	if search.SortBy == "" {
+		search.SortBy = "apy"
+	}
+	if err := validateYieldPoolSearch(search); err != nil {
+		return nil, 0, err
+	}
+	if search.Limit <= 0 {
+		search.Limit = 20
+	}
+	if search.Limit > 100 {
+		search.Limit = 100
+	}
+	if search.Offset < 0 {
+		search.Offset = 0
+	}
+
+	pools, total, err := s.poolRepo.Search(ctx, search)
+	if err != nil {
+		return nil, 0, errors.Internal("Failed to search yield pools")
+	}
+	return pools, total, nil
+}
+
+// validateYieldPoolSearch checks the filters of a pool search; paging is clamped instead
+func validateYieldPoolSearch(search repos.YieldPoolSearch) error {
+	if search.SortBy != "" && !repos.IsYieldPoolSortField(search.SortBy) {
+		return errors.BadRequest("Unknown sort field " + search.SortBy)
+	}
+	for _, risk := range search.RiskLevels {
+		if !yieldRiskLevels[risk] {
+			return errors.BadRequest("Risk level must be low, medium or high")
+		}
+	}
+	if search.MinAPY != nil && search.MaxAPY != nil && *search.MinAPY > *search.MaxAPY {
+		return errors.BadRequest("minApy must not be greater than maxApy")
+	}
+	if search.MinTVL != nil && search.MaxTVL != nil && *search.MinTVL > *search.MaxTVL {
+		return errors.BadRequest("minTvl must not be greater than maxTvl")
+	}
+	return nil
+}
+
+// yieldSearchParams are the query parameters YieldPoolSearchFromParams reads
+var yieldSearchParams = map[string]bool{
+	"q": true, "chains": true, "risk": true, "protocols": true,
+	"minApy": true, "maxApy": true, "minTvl": true, "maxTvl": true,
+	"stablecoin": true, "active": true, "sort": true, "order": true,
+}
+
+// YieldPoolSearchFromParams builds a pool search from the query parameters of
+// GET /yield/pools/search, leaving paging to the caller. List parameters take
+// comma-separated values, e.g. chains=1,137&risk=low,medium; sort takes a field with
+// order=asc|desc. Other parameters are ignored.
+func YieldPoolSearchFromParams(params map[string]string) (repos.YieldPoolSearch, error) {
+	search := repos.YieldPoolSearch{
+		Text:          params["q"],
+		RiskLevels:    splitParamList(params["risk"]),
+		ProtocolSlugs: splitParamList(params["protocols"]),
+		SortBy:        params["sort"],
+		SortAsc:       params["order"] == "asc",
+	}
+	if search.SortBy == "" {
+		search.SortBy = "apy"
+	}
+
+	for _, v := range splitParamList(params["chains"]) {
+		chainID, err := strconv.Atoi(v)
+		if err != nil {
+			return search, errors.BadRequest("chains must be a comma-separated list of chain IDs")
+		}
+		search.ChainIDs = append(search.ChainIDs, chainID)
+	}
+
+	for key, dst := range map[string]**float64{
+		"minApy": &search.MinAPY,
+		"maxApy": &search.MaxAPY,
+		"minTvl": &search.MinTVL,
+		"maxTvl": &search.MaxTVL,
+	} {
+		if params[key] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(params[key], 64)
+		if err != nil {
+			return search, errors.BadRequest(key + " must be a number")
+		}
+		*dst = &v
+	}
+
+	if v := params["stablecoin"]; v != "" {
+		stablecoin, err := strconv.ParseBool(v)
+		if err != nil {
+			return search, errors.BadRequest("stablecoin must be true or false")
+		}
+		search.StablecoinOnly = stablecoin
+	}
+	if v := params["active"]; v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return search, errors.BadRequest("active must be true or false")
+		}
+		search.IsActive = &active
+	}
+
+	return search, nil
+}
+
+// splitParamList splits a comma-separated parameter, dropping empty values
+func splitParamList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func (s *YieldService) GetPoolByID(ctx context.Context, poolID uuid.UUID) (*models.YieldPool, error) {
 	pool, err := s.poolRepo.GetByID(ctx, poolID)
 	if err != nil {
-		return nil, errors.NotFound("Yield pool not found")
+		return nil, repoError(err, "Yield pool")
 	}
 
 	return pool, nil
@@ -82,15 +235,17 @@ func (s *YieldService) GetTopPoolsByTVL(ctx context.Context, limit int) ([]*mode
 
 func (s *YieldService) RefreshPoolData(ctx context.Context, poolID string, tvlUSD, apy, apyBase, apyReward float64) error {
 	// Update pool APY and TVL - this would typically be called by the worker
-	if err := s.poolRepo.UpdateAPY(ctx, poolID, apy, apyBase, apyReward); err != nil {
-		return errors.Internal("Failed to update pool APY")
-	}
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.poolRepo.UpdateAPY(ctx, poolID, apy, apyBase, apyReward); err != nil {
+			return errors.Internal("Failed to update pool APY")
+		}
 
-	if err := s.poolRepo.UpdateTVL(ctx, poolID, tvlUSD); err != nil {
-		return errors.Internal("Failed to update pool TVL")
-	}
+		if err := s.poolRepo.UpdateTVL(ctx, poolID, tvlUSD); err != nil {
+			return errors.Internal("Failed to update pool TVL")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // Position Management
@@ -99,7 +254,7 @@ func (s *YieldService) GetUserPositions(ctx context.Context, userAddress string,
 	// Get user by address
 	user, err := s.userRepo.GetByAddress(ctx, userAddress)
 	if err != nil {
-		return nil, errors.NotFound("User not found")
+		return nil, repoError(err, "User")
 	}
 
 	// Get user positions with pool information
@@ -117,6 +272,7 @@ func (s *YieldService) GetUserPositions(ctx context.Context, userAddress string,
 	// Set positions in summary
 	summary.Positions = make([]models.YieldPosition, len(positions))
 	for i, pos := range positions {
+		pos.RewardComposition = rewardComposition(pos.PendingRewards)
 		summary.Positions[i] = *pos
 	}
 
@@ -126,8 +282,9 @@ func (s *YieldService) GetUserPositions(ctx context.Context, userAddress string,
 func (s *YieldService) GetPositionByID(ctx context.Context, positionID uuid.UUID) (*models.YieldPosition, error) {
 	position, err := s.positionRepo.GetByID(ctx, positionID)
 	if err != nil {
-		return nil, errors.NotFound("Position not found")
+		return nil, repoError(err, "Position")
 	}
+	position.RewardComposition = rewardComposition(position.PendingRewards)
 
 	return position, nil
 }
@@ -136,13 +293,13 @@ func (s *YieldService) CreatePosition(ctx context.Context, userAddress string, r
 	// Get user by address
 	user, err := s.userRepo.GetByAddress(ctx, userAddress)
 	if err != nil {
-		return nil, errors.NotFound("User not found")
+		return nil, repoError(err, "User")
 	}
 
 	// Validate pool exists
 	pool, err := s.poolRepo.GetByID(ctx, req.PoolID)
 	if err != nil {
-		return nil, errors.NotFound("Pool not found")
+		return nil, repoError(err, "Yield pool")
 	}
 
 	// Create position
@@ -159,25 +316,97 @@ func (s *YieldService) CreatePosition(ctx context.Context, userAddress string, r
 		EntryPriceUSD:        req.EntryPriceUSD,
 		EntryBlockNumber:     req.EntryBlockNumber,
 		EntryTransactionHash: req.EntryTransactionHash,
-		EntryTime:            time.Now(),
+		EntryTime:            s.clock.Now(),
+		EntryTokenPrices:     lowerKeys(req.EntryTokenPrices),
 		CurrentValueUSD:      req.BalanceUSD, // Initially same as balance
 		IsActive:             true,
 		Metadata:             req.Metadata,
 	}
 
-	createdPosition, err := s.positionRepo.Create(ctx, position)
+	// The position, its carried-over history and its alert are committed together. The
+	// carry-over and alert are each undone on their own when they fail, leaving the
+	// position as created.
+	var createdPosition *models.YieldPosition
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		created, err := s.positionRepo.Create(ctx, position)
+		if err != nil {
+			return repoError(err, "Position")
+		}
+		createdPosition = created
+
+		if s.migrations != nil {
+			var from *uuid.UUID
+			err := s.uow.Do(ctx, func(ctx context.Context) (err error) {
+				from, err = s.migrations.CarryOver(ctx, created.ID)
+				return err
+			})
+			if err != nil {
+				logger.Warn("Failed to carry over migrated position", "positionId", created.ID, "error", err)
+			} else if from != nil {
+				if carried, err := s.positionRepo.GetByID(ctx, created.ID); err == nil {
+					createdPosition = carried
+				}
+			}
+		}
+
+		if req.APYAlert != nil && s.alertService != nil {
+			err := s.uow.Do(ctx, func(ctx context.Context) error {
+				_, err := s.alertService.CreateAlert(ctx, user.ID, positionAPYAlertRequest(created.ID, pool, req.APYAlert))
+				return err
+			})
+			if err != nil {
+				logger.Warn("Failed to create position APY alert", "positionId", created.ID, "error", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, errors.Internal("Failed to create position")
+		return nil, err
 	}
 
 	return createdPosition, nil
 }
 
+// lowerKeys returns prices keyed by lowercase token address
+func lowerKeys(prices map[string]float64) map[string]float64 {
+	if prices == nil {
+		return nil
+	}
+	lowered := make(map[string]float64, len(prices))
+	for address, price := range prices {
+		lowered[strings.ToLower(address)] = price
+	}
+	return lowered
+}
+
+// positionAPYAlertRequest builds the alert watching a new position's pool APY, with the
+// pool's current APY as the baseline drops are measured from
+func positionAPYAlertRequest(positionID uuid.UUID, pool *models.YieldPool, settings *PositionAPYAlertSettings) *models.CreateAlertRequest {
+	conditions := models.AlertConditions{
+		APYDropPercent: settings.DropPercent,
+		MinAPR:         settings.MinAPY,
+	}
+	if pool.APY != nil && *pool.APY > 0 {
+		baseline := *pool.APY
+		conditions.BaselineAPY = &baseline
+	}
+
+	return &models.CreateAlertRequest{
+		Type: models.AlertTypePositionAPYDrop,
+		Target: models.AlertTarget{
+			Type:       models.AlertTargetTypePosition,
+			Identifier: positionID.String(),
+		},
+		Conditions:   conditions,
+		Notification: models.AlertNotification{Email: settings.Email, Webhook: settings.Webhook},
+	}
+}
+
 func (s *YieldService) UpdatePosition(ctx context.Context, positionID uuid.UUID, req UpdatePositionRequest) (*models.YieldPosition, error) {
 	// Get existing position
 	position, err := s.positionRepo.GetByID(ctx, positionID)
 	if err != nil {
-		return nil, errors.NotFound("Position not found")
+		return nil, repoError(err, "Position")
 	}
 
 	// Update balance if provided
@@ -205,7 +434,7 @@ func (s *YieldService) UpdatePosition(ctx context.Context, positionID uuid.UUID,
 	}
 
 	// Update last update time
-	now := time.Now()
+	now := s.clock.Now()
 	position.LastUpdateTime = &now
 	if req.LastUpdateBlock != nil {
 		position.LastUpdateBlock = req.LastUpdateBlock
@@ -231,13 +460,13 @@ func (s *YieldService) ClaimRewards(ctx context.Context, userAddress string, pos
 	// Get user by address
 	user, err := s.userRepo.GetByAddress(ctx, userAddress)
 	if err != nil {
-		return nil, errors.NotFound("User not found")
+		return nil, repoError(err, "User")
 	}
 
 	// Get position and verify ownership
 	position, err := s.positionRepo.GetByID(ctx, positionID)
 	if err != nil {
-		return nil, errors.NotFound("Position not found")
+		return nil, repoError(err, "Position")
 	}
 
 	if position.UserID != user.ID {
@@ -257,7 +486,7 @@ func (s *YieldService) ClaimRewards(ctx context.Context, userAddress string, pos
 
 		// Add claim timestamp to rewards
 		for _, reward := range position.PendingRewards {
-			now := time.Now()
+			now := s.clock.Now()
 			reward.ClaimedAt = &now
 			position.ClaimedRewards = append(position.ClaimedRewards, reward)
 		}
@@ -274,7 +503,7 @@ func (s *YieldService) ClaimRewards(ctx context.Context, userAddress string, pos
 
 	return &ClaimResponse{
 		TransactionHash: txHash,
-		ClaimedAt:       time.Now(),
+		ClaimedAt:       s.clock.Now(),
 		Status:          "pending", // Would be "confirmed" after blockchain confirmation
 	}, nil
 }
@@ -307,12 +536,92 @@ func (s *YieldService) GetProtocols(ctx context.Context, filters repos.ProtocolF
 func (s *YieldService) GetProtocolBySlug(ctx context.Context, slug string) (*models.Protocol, error) {
 	protocol, err := s.protocolRepo.GetBySlug(ctx, slug)
 	if err != nil {
-		return nil, errors.NotFound("Protocol not found")
+		return nil, repoError(err, "Protocol")
 	}
 
 	return protocol, nil
 }
 
+// protocolTVLPeriods maps the supported history periods to their length
+var protocolTVLPeriods = map[string]time.Duration{
+	"1d": 24 * time.Hour,
+	"1w": 7 * 24 * time.Hour,
+	"1m": 30 * 24 * time.Hour,
+	"3m": 90 * 24 * time.Hour,
+	"1y": 365 * 24 * time.Hour,
+}
+
+// GetProtocolTVL returns a protocol's latest TVL, chain breakdown and TVL history over period
+func (s *YieldService) GetProtocolTVL(ctx context.Context, slug, period string) (*models.ProtocolTVL, error) {
+	window, ok := protocolTVLPeriods[period]
+	if !ok {
+		return nil, errors.BadRequest("Invalid period. Must be one of: 1d, 1w, 1m, 3m, 1y")
+	}
+
+	protocol, err := s.GetProtocolBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	// Always load at least a day so the 24h change is available for short periods
+	since := s.clock.Now().Add(-window)
+	if window < 48*time.Hour {
+		since = s.clock.Now().Add(-48 * time.Hour)
+	}
+	history, err := s.tvlRepo.GetHistory(ctx, protocol.ID, since)
+	if err != nil {
+		logger.Error("Failed to get protocol TVL history", "error", err, "protocol", slug)
+		return nil, errors.Internal("Failed to fetch protocol TVL")
+	}
+
+	result := &models.ProtocolTVL{
+		Protocol:         protocol,
+		ChainTVLs:        map[string]float64{},
+		Change24hPercent: tvlChange24h(history),
+		History:          make([]*models.ProtocolTVLSnapshot, 0, len(history)),
+	}
+	if protocol.TotalTVLUSD != nil {
+		result.TVLUSD = *protocol.TotalTVLUSD
+	}
+	if len(history) > 0 {
+		latest := history[len(history)-1]
+		result.TVLUSD = latest.TVLUSD
+		result.ChainTVLs = latest.ChainTVLs
+	}
+
+	cutoff := s.clock.Now().Add(-window)
+	for _, snapshot := range history {
+		if !snapshot.RecordedAt.Before(cutoff) {
+			result.History = append(result.History, snapshot)
+		}
+	}
+
+	return result, nil
+}
+
+// tvlChange24h compares the latest snapshot with the latest one at least 24 hours older;
+// history must be oldest first. Returns nil without a day of history.
+func tvlChange24h(history []*models.ProtocolTVLSnapshot) *float64 {
+	if len(history) < 2 {
+		return nil
+	}
+
+	latest := history[len(history)-1]
+	dayAgo := latest.RecordedAt.Add(-24 * time.Hour)
+	for i := len(history) - 2; i >= 0; i-- {
+		if history[i].RecordedAt.After(dayAgo) {
+			continue
+		}
+		if history[i].TVLUSD == 0 {
+			return nil
+		}
+		change := (latest.TVLUSD - history[i].TVLUSD) / history[i].TVLUSD * 100
+		return &change
+	}
+
+	return nil
+}
+
 // Helper methods
 
 func (s *YieldService) generateMockTxHash() string {
@@ -336,6 +645,19 @@ type CreatePositionRequest struct {
 	EntryBlockNumber     *int64      `json:"entry_block_number,omitempty"`
 	EntryTransactionHash *string     `json:"entry_transaction_hash,omitempty"`
 	Metadata             interface{} `json:"metadata,omitempty"`
+	// EntryTokenPrices are the pool tokens' USD prices at entry by address; the tokens'
+	// current prices are used when omitted
+	EntryTokenPrices map[string]float64 `json:"entry_token_prices,omitempty"`
+	// APYAlert optionally creates an alert for APY drops on the position's pool
+	APYAlert *PositionAPYAlertSettings `json:"apy_alert,omitempty"`
+}
+
+// PositionAPYAlertSettings configures the APY alert created with a position
+type PositionAPYAlertSettings struct {
+	DropPercent *float64 `json:"drop_percent,omitempty"` // relative drop from the current APY
+	MinAPY      *float64 `json:"min_apy,omitempty"`
+	Email       bool     `json:"email"`
+	Webhook     string   `json:"webhook,omitempty"`
 }
 
 type UpdatePositionRequest struct {
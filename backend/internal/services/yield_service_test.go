@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTVLChange24h(t *testing.T) {
+	now := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	snapshot := func(ago time.Duration, tvl float64) *models.ProtocolTVLSnapshot {
+		return &models.ProtocolTVLSnapshot{TVLUSD: tvl, RecordedAt: now.Add(-ago)}
+	}
+
+	history := []*models.ProtocolTVLSnapshot{
+		snapshot(26*time.Hour, 900),
+		snapshot(24*time.Hour, 1000),
+		snapshot(12*time.Hour, 1500),
+		snapshot(0, 1100),
+	}
+	change := tvlChange24h(history)
+	require.NotNil(t, change)
+	assert.InDelta(t, 10.0, *change, 1e-9)
+
+	// Less than a day of history
+	assert.Nil(t, tvlChange24h(history[2:]))
+	assert.Nil(t, tvlChange24h(nil))
+}
+
+func TestPositionAPYAlertRequest(t *testing.T) {
+	positionID := uuid.New()
+	apy, drop := 7.5, 20.0
+	pool := &models.YieldPool{APY: &apy}
+
+	req := positionAPYAlertRequest(positionID, pool, &PositionAPYAlertSettings{DropPercent: &drop, Email: true})
+
+	assert.Equal(t, models.AlertTypePositionAPYDrop, req.Type)
+	assert.Equal(t, models.AlertTarget{Type: models.AlertTargetTypePosition, Identifier: positionID.String()}, req.Target)
+	require.NotNil(t, req.Conditions.BaselineAPY)
+	assert.Equal(t, apy, *req.Conditions.BaselineAPY)
+	assert.Equal(t, drop, *req.Conditions.APYDropPercent)
+	assert.Nil(t, req.Conditions.MinAPR)
+	assert.True(t, req.Notification.Email)
+
+	// A pool without an APY yet leaves the baseline to the evaluator
+	req = positionAPYAlertRequest(positionID, &models.YieldPool{}, &PositionAPYAlertSettings{DropPercent: &drop})
+	assert.Nil(t, req.Conditions.BaselineAPY)
+}
+
+func TestPositionTxProtocol(t *testing.T) {
+	pool := func(slug string, metadata interface{}) *models.YieldPool {
+		return &models.YieldPool{Protocol: &models.Protocol{Slug: slug}, Metadata: metadata}
+	}
+
+	assert.Equal(t, "aave-v3", positionTxProtocol(pool("aave-v3", nil)))
+	assert.Equal(t, "compound-v3", positionTxProtocol(pool("compound-v3", nil)))
+	assert.Equal(t, "erc4626", positionTxProtocol(pool("yearn-finance", nil)))
+	assert.Equal(t, "erc4626", positionTxProtocol(pool("sommelier", map[string]interface{}{"erc4626": true})))
+	assert.Equal(t, "", positionTxProtocol(pool("curve-dex", map[string]interface{}{"erc4626": "yes"})))
+	assert.Equal(t, "", positionTxProtocol(&models.YieldPool{}))
+}
+
+func TestPoolRewards(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	soon := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	later := soon.AddDate(0, 1, 0)
+	pool := &models.YieldPool{ID: uuid.New(), APY: value(5), APYBase: value(3), APYReward: value(2)}
+
+	result := poolRewards(pool, []models.RewardAPR{
+		{Token: "0xa", APR: value(1.5), EndsAt: &later, Source: models.RewardSourceOnchain},
+		{Token: "0xb", APR: value(0.5), EndsAt: &soon, Source: models.RewardSourceOnchain},
+	})
+	require.NotNil(t, result.EmissionShare)
+	assert.InDelta(t, 40, *result.EmissionShare, 1e-9)
+	assert.Equal(t, &soon, result.EmissionsEndAt)
+	assert.Len(t, result.Rewards, 2)
+
+	// Pools without rewards still list an empty breakdown
+	result = poolRewards(&models.YieldPool{APY: value(0)}, nil)
+	assert.NotNil(t, result.Rewards)
+	assert.Nil(t, result.EmissionShare)
+}
+
+func TestRewardComposition(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	arb, op, unpriced := uuid.New(), uuid.New(), uuid.New()
+
+	shares := rewardComposition([]models.RewardInfo{
+		{TokenID: arb, Token: &models.Token{Symbol: "ARB"}, AmountUSD: value(30)},
+		{TokenID: op, AmountUSD: value(10)},
+		{TokenID: arb, AmountUSD: value(40)},
+		{TokenID: unpriced},
+	})
+	require.Len(t, shares, 3)
+	assert.Equal(t, "ARB", shares[0].Symbol)
+	assert.InDelta(t, 70, *shares[0].AmountUSD, 1e-9)
+	assert.InDelta(t, 87.5, *shares[0].Share, 1e-9)
+	assert.InDelta(t, 12.5, *shares[1].Share, 1e-9)
+	assert.Nil(t, shares[2].Share)
+
+	assert.Empty(t, rewardComposition(nil))
+}
+
+// failingPools and failingPositions fail with err the way the postgres repos report
+// missing rows, unique violations and broken connections
+type failingPools struct {
+	repos.YieldPoolRepository
+	err error
+}
+
+func (r failingPools) GetByID(_ context.Context, id uuid.UUID) (*models.YieldPool, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.YieldPool{ID: id}, nil
+}
+
+// UpdateAPY succeeds so UpdateTVL fails after a write
+func (r failingPools) UpdateAPY(context.Context, string, float64, float64, float64) error {
+	return nil
+}
+
+func (r failingPools) UpdateTVL(context.Context, string, float64) error {
+	return r.err
+}
+
+type failingPositions struct {
+	repos.YieldPositionRepository
+	err error
+}
+
+func (r failingPositions) GetByID(context.Context, uuid.UUID) (*models.YieldPosition, error) {
+	return nil, r.err
+}
+
+func (r failingPositions) Create(_ context.Context, position *models.YieldPosition) (*models.YieldPosition, error) {
+	return position, r.err
+}
+
+type knownUsers struct {
+	repos.UserRepository
+}
+
+func (knownUsers) GetByAddress(_ context.Context, address string) (*models.User, error) {
+	return &models.User{ID: uuid.New(), Address: address}, nil
+}
+
+func TestYieldServiceRepoErrorStatuses(t *testing.T) {
+	ctx := context.Background()
+	appError := func(err error) *errors.AppError {
+		var appErr *errors.AppError
+		require.True(t, stderrors.As(err, &appErr), "expected an AppError, got %v", err)
+		return appErr
+	}
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"missing row", fmt.Errorf("yield pool %w: no rows in result set", repos.ErrNotFound), 404},
+		{"unique violation", fmt.Errorf("yield position %w: duplicate key", repos.ErrConflict), 409},
+		{"connection failure", stderrors.New("dial tcp: connection refused"), 500},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			positions := failingPositions{err: tc.err}
+			service := NewYieldService(failingPools{err: tc.err}, positions, nil, nil, knownUsers{}, nil)
+
+			_, err := service.GetPoolByID(ctx, uuid.New())
+			assert.Equal(t, tc.status, appError(err).Status, "pool lookup")
+			_, err = service.GetPositionByID(ctx, uuid.New())
+			assert.Equal(t, tc.status, appError(err).Status, "position lookup")
+
+			// The pool is found, so only the write fails
+			service = NewYieldService(failingPools{}, positions, nil, nil, knownUsers{}, nil)
+			_, err = service.CreatePosition(ctx, "0xuser", CreatePositionRequest{PoolID: uuid.New()})
+			assert.Equal(t, tc.status, appError(err).Status, "position create")
+		})
+	}
+
+	service := NewYieldService(failingPools{err: repos.ErrYieldPoolNotFound}, nil, nil, nil, nil, nil)
+	_, err := service.GetPoolByID(ctx, uuid.New())
+	assert.Equal(t, "Yield pool not found", appError(err).Message)
+}
+
+// recordingUnitOfWork runs units of work directly, noting which committed and which
+// rolled back, outermost last
+type recordingUnitOfWork struct {
+	committed, rolledBack int
+}
+
+func (u *recordingUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		u.rolledBack++
+		return err
+	}
+	u.committed++
+	return nil
+}
+
+type rejectingAlerts struct {
+	AlertService
+}
+
+func (rejectingAlerts) CreateAlert(context.Context, uuid.UUID, *models.CreateAlertRequest) (*models.Alert, error) {
+	return nil, errors.BadRequest("Webhook URL is not allowed")
+}
+
+func TestCreatePositionCommitsWithoutFailedAlert(t *testing.T) {
+	ctx := context.Background()
+	uow := &recordingUnitOfWork{}
+	service := NewYieldService(failingPools{}, failingPositions{}, nil, nil, knownUsers{}, rejectingAlerts{})
+	service.SetUnitOfWork(uow)
+
+	position, err := service.CreatePosition(ctx, "0xuser", CreatePositionRequest{
+		PoolID:   uuid.New(),
+		APYAlert: &PositionAPYAlertSettings{Webhook: "http://localhost"},
+	})
+	require.NoError(t, err, "a rejected alert doesn't fail the position")
+	assert.NotNil(t, position)
+	assert.Equal(t, 1, uow.rolledBack, "only the alert's writes are undone")
+	assert.Equal(t, 1, uow.committed)
+
+	// The TVL failing undoes the APY already written
+	uow = &recordingUnitOfWork{}
+	service = NewYieldService(failingPools{err: stderrors.New("connection reset")}, nil, nil, nil, nil, nil)
+	service.SetUnitOfWork(uow)
+	assert.Error(t, service.RefreshPoolData(ctx, "pool", 1000, 5, 4, 1))
+	assert.Equal(t, 1, uow.rolledBack)
+	assert.Zero(t, uow.committed)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/errors"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+)
+
+// Position transaction actions
+const (
+	PositionActionEnter = "enter"
+	PositionActionExit  = "exit"
+)
+
+// erc4626ProtocolSlugs are protocols whose pools are ERC-4626 vaults. Pools of other
+// protocols count as vaults when their metadata sets "erc4626".
+var erc4626ProtocolSlugs = map[string]bool{
+	"yearn-finance": true,
+	"morpho-blue":   true,
+	"spark":         true,
+	"euler-v2":      true,
+	"fluid":         true,
+}
+
+// PositionTxsRequest asks for the transactions entering or leaving a pool
+type PositionTxsRequest struct {
+	Action string `json:"action"`
+	// Amount is in the pool token's base units; "max" withdraws the whole position
+	Amount string `json:"amount"`
+	Owner  string `json:"owner"`
+	// Claim claims the position's rewards before withdrawing
+	Claim bool `json:"claim"`
+}
+
+// PositionTxs are the transactions to sign, in order, to enter or leave a pool
+type PositionTxs struct {
+	PoolID       uuid.UUID               `json:"poolId"`
+	Protocol     string                  `json:"protocol"`
+	ChainID      int                     `json:"chainId"`
+	Action       string                  `json:"action"`
+	Transactions []blockchain.PositionTx `json:"transactions"`
+}
+
+// BuildPositionTxs returns unsigned approve and deposit transactions entering a pool, or
+// withdraw and claim transactions leaving it, for Aave v3, Compound v3 and ERC-4626 vaults.
+// Leaving a vault entirely reads the owner's shares with alchemyAPIKey.
+func (s *YieldService) BuildPositionTxs(ctx context.Context, poolID uuid.UUID, req PositionTxsRequest, alchemyAPIKey string) (*PositionTxs, error) {
+	if req.Action != PositionActionEnter && req.Action != PositionActionExit {
+		return nil, errors.BadRequest("Action must be enter or exit")
+	}
+
+	pool, err := s.poolRepo.GetByID(ctx, poolID)
+	if err != nil {
+		return nil, repoError(err, "Yield pool")
+	}
+	protocol := positionTxProtocol(pool)
+	if protocol == "" {
+		return nil, errors.BadRequest("Transactions can't be built for this pool's protocol")
+	}
+	if pool.ChainID == nil || len(pool.TokenAddresses) == 0 {
+		return nil, errors.BadRequest("Pool is missing its chain or token")
+	}
+	asset := pool.TokenAddresses[0]
+	if isNativeTokenPlaceholder(asset) {
+		return nil, errors.BadRequest("Pools of the native token need it wrapped first")
+	}
+
+	txReq := blockchain.PositionTxRequest{
+		ChainID: *pool.ChainID,
+		Asset:   asset,
+		Owner:   req.Owner,
+		Claim:   req.Claim,
+		Receipt: poolMetadataString(pool, "receipt_token"),
+	}
+	if pool.PoolAddress != nil {
+		txReq.Market = *pool.PoolAddress
+	}
+	// Aave v3 has one Pool per chain, so the pool address is the reserve's aToken if set
+	if protocol == blockchain.PositionProtocolAaveV3 {
+		if txReq.Receipt == "" {
+			txReq.Receipt = txReq.Market
+		}
+		txReq.Market = ""
+	}
+
+	if req.Action == PositionActionExit && strings.EqualFold(req.Amount, "max") {
+		txReq.Amount = nil
+		if protocol == blockchain.PositionProtocolERC4626 && common.IsHexAddress(txReq.Market) && common.IsHexAddress(req.Owner) {
+			blockchainService := blockchain.NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, "")
+			shares, err := blockchainService.GetVaultShares(ctx, txReq.ChainID, txReq.Market, req.Owner)
+			if err != nil {
+				logger.Error("Failed to read vault shares", "error", err, "vault", txReq.Market, "owner", req.Owner)
+				return nil, errors.Internal("Failed to read vault shares")
+			}
+			if shares.Sign() == 0 {
+				return nil, errors.BadRequest("Owner holds no shares in this vault")
+			}
+			txReq.Shares = shares
+		}
+	} else {
+		amount, ok := new(big.Int).SetString(req.Amount, 10)
+		if !ok || amount.Sign() <= 0 {
+			return nil, errors.BadRequest("Amount must be a positive integer in base units")
+		}
+		txReq.Amount = amount
+	}
+
+	var txs []blockchain.PositionTx
+	if req.Action == PositionActionEnter {
+		txs, err = blockchain.BuildEnterPositionTxs(protocol, txReq)
+	} else {
+		txs, err = blockchain.BuildExitPositionTxs(protocol, txReq)
+	}
+	if err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+
+	return &PositionTxs{
+		PoolID:       pool.ID,
+		Protocol:     protocol,
+		ChainID:      *pool.ChainID,
+		Action:       req.Action,
+		Transactions: txs,
+	}, nil
+}
+
+// positionTxProtocol returns the transaction builder protocol for the pool, or "" when
+// transactions can't be built for it
+func positionTxProtocol(pool *models.YieldPool) string {
+	slug := ""
+	if pool.Protocol != nil {
+		slug = pool.Protocol.Slug
+	}
+	switch {
+	case slug == blockchain.PositionProtocolAaveV3, slug == blockchain.PositionProtocolCompoundV3:
+		return slug
+	case erc4626ProtocolSlugs[slug]:
+		return blockchain.PositionProtocolERC4626
+	}
+	if metadata, ok := pool.Metadata.(map[string]interface{}); ok {
+		if vault, _ := metadata["erc4626"].(bool); vault {
+			return blockchain.PositionProtocolERC4626
+		}
+	}
+	return ""
+}
+
+func poolMetadataString(pool *models.YieldPool, key string) string {
+	metadata, ok := pool.Metadata.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	value, _ := metadata[key].(string)
+	return value
+}
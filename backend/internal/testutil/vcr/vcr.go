@@ -0,0 +1,175 @@
+// Package vcr replays recorded provider responses from JSON cassettes, so client tests
+// run against real response shapes without network access or API keys.
+//
+// A cassette lists the requests a test is expected to make, in order, with the response
+// to play back for each:
+//
+//	{
+//	  "interactions": [
+//	    {
+//	      "request": {"method": "GET", "path": "/quote", "query": {"fromChain": "1"}},
+//	      "response": {"status": 200, "body": {"routes": []}}
+//	    }
+//	  ]
+//	}
+//
+// A request matches when it has the recorded method and path, carries at least the
+// recorded query parameters and headers and, if the cassette records a body, a body that
+// is equal as JSON. Response bodies are JSON unless "text" is given instead.
+package vcr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// Request is the part of a recorded request a replayed one must match
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   map[string]string `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response is played back for a matching request
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Text    string            `json:"text,omitempty"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the recording loaded from a fixture file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads the cassette at path, failing the test if it can't be parsed
+func Load(t testing.TB, path string) *Cassette {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("vcr: failed to read cassette: %v", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		t.Fatalf("vcr: failed to parse cassette %s: %v", path, err)
+	}
+	return &cassette
+}
+
+// Replay serves the cassette at path from a test server that is closed when the test
+// ends. The test fails on a request that doesn't match the next interaction, and on
+// interactions that were never played.
+func Replay(t testing.TB, path string) *httptest.Server {
+	t.Helper()
+	cassette := Load(t, path)
+
+	var mu sync.Mutex
+	played := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if played == len(cassette.Interactions) {
+			t.Errorf("vcr: %s: unexpected request %s %s", path, r.Method, r.URL)
+			http.Error(w, "no interaction recorded", http.StatusNotImplemented)
+			return
+		}
+		interaction := cassette.Interactions[played]
+		played++
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("vcr: %s: failed to read request body: %v", path, err)
+		}
+		if err := interaction.Request.match(r, body); err != nil {
+			t.Errorf("vcr: %s: interaction %d: %v", path, played, err)
+		}
+		interaction.Response.write(w)
+	}))
+
+	t.Cleanup(func() {
+		server.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		if played < len(cassette.Interactions) {
+			t.Errorf("vcr: %s: %d of %d interactions were never played", path, len(cassette.Interactions)-played, len(cassette.Interactions))
+		}
+	})
+	return server
+}
+
+func (req Request) match(r *http.Request, body []byte) error {
+	if req.Method != "" && r.Method != req.Method {
+		return fmt.Errorf("method %s, recorded %s", r.Method, req.Method)
+	}
+	if r.URL.Path != req.Path {
+		return fmt.Errorf("path %s, recorded %s", r.URL.Path, req.Path)
+	}
+
+	query := r.URL.Query()
+	for key, value := range req.Query {
+		if got := query.Get(key); got != value {
+			return fmt.Errorf("query %s=%q, recorded %q", key, got, value)
+		}
+	}
+	for key, value := range req.Headers {
+		if got := r.Header.Get(key); got != value {
+			return fmt.Errorf("header %s=%q, recorded %q", key, got, value)
+		}
+	}
+
+	if len(req.Body) > 0 {
+		var expected, actual interface{}
+		if err := json.Unmarshal(req.Body, &expected); err != nil {
+			return fmt.Errorf("recorded body is not JSON: %w", err)
+		}
+		if err := json.Unmarshal(body, &actual); err != nil {
+			return fmt.Errorf("body is not JSON: %w", err)
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("body %s, recorded %s", body, req.Body)
+		}
+	}
+	return nil
+}
+
+func (resp Response) write(w http.ResponseWriter) {
+	for key, value := range resp.Headers {
+		w.Header().Set(key, value)
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if resp.Text != "" {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, resp.Text)
+		return
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(resp.Body)
+}
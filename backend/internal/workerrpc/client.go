@@ -0,0 +1,75 @@
+package workerrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/google/uuid"
+)
+
+// Client asks the worker to run tasks
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the worker RPC server at baseURL
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: correlation.NewTransport(nil)},
+	}
+}
+
+// SyncWallet asks the worker to sync a wallet now
+func (c *Client) SyncWallet(ctx context.Context, userID, walletID uuid.UUID) (*TaskAccepted, error) {
+	return c.Run(ctx, TaskSyncWallet, TaskRequest{UserID: userID, TargetID: walletID})
+}
+
+// EvaluateAlert asks the worker to evaluate an alert now
+func (c *Client) EvaluateAlert(ctx context.Context, userID, alertID uuid.UUID) (*TaskAccepted, error) {
+	return c.Run(ctx, TaskEvaluateAlert, TaskRequest{UserID: userID, TargetID: alertID})
+}
+
+// Run queues a task on the worker; its progress is published as realtime events
+func (c *Client) Run(ctx context.Context, task string, req TaskRequest) (*TaskAccepted, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal task request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/tasks/"+task, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach worker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return nil, fmt.Errorf("worker rejected task %s with status %d: %s", task, resp.StatusCode, e.Error)
+	}
+
+	var accepted TaskAccepted
+	if err := json.NewDecoder(resp.Body).Decode(&accepted); err != nil {
+		return nil, fmt.Errorf("failed to decode worker response: %w", err)
+	}
+	return &accepted, nil
+}
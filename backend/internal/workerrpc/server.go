@@ -0,0 +1,191 @@
+package workerrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// defaultTaskTimeout bounds a task like runJob bounds a scheduled job
+const defaultTaskTimeout = 5 * time.Minute
+
+// TaskFunc runs a task, calling progress as each step starts, and returns a summary
+// of what it did for the completion event
+type TaskFunc func(ctx context.Context, req TaskRequest, progress func(step string)) (map[string]interface{}, error)
+
+// Server runs tasks requested by the API. A task already running for the same target
+// isn't started twice; the caller gets the running task's ID instead.
+type Server struct {
+	ctx       context.Context
+	token     string
+	publisher events.Publisher
+	timeout   time.Duration
+	tasks     map[string]TaskFunc
+
+	mu       sync.Mutex
+	inFlight map[string]uuid.UUID
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a server whose tasks run under ctx, so cancelling it stops them
+func NewServer(ctx context.Context, token string, publisher events.Publisher) *Server {
+	return &Server{
+		ctx:       ctx,
+		token:     token,
+		publisher: publisher,
+		timeout:   defaultTaskTimeout,
+		tasks:     make(map[string]TaskFunc),
+		inFlight:  make(map[string]uuid.UUID),
+	}
+}
+
+// Register makes a task available to the API
+func (s *Server) Register(task string, fn TaskFunc) {
+	s.tasks[task] = fn
+}
+
+// Handler serves task requests at POST /tasks/{task} and the worker's expvar metrics
+// at GET /metrics
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks/{task}", s.handleTask)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return mux
+}
+
+// Wait blocks until running tasks have finished
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid worker RPC token")
+		return
+	}
+
+	task := r.PathValue("task")
+	fn, ok := s.tasks[task]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown task "+task)
+		return
+	}
+
+	var req TaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid task request")
+		return
+	}
+	if req.UserID == uuid.Nil || req.TargetID == uuid.Nil {
+		writeError(w, http.StatusBadRequest, "user_id and target_id are required")
+		return
+	}
+
+	// The API's request ID follows the task into its logs and provider calls
+	requestID := r.Header.Get(correlation.Header)
+	taskID, started := s.start(task, fn, req, requestID)
+	if started {
+		logger.Info("Worker task accepted", "task", task, "taskId", taskID, "targetId", req.TargetID, "request_id", requestID)
+	} else {
+		logger.Info("Worker task already running", "task", task, "taskId", taskID, "targetId", req.TargetID, "request_id", requestID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(TaskAccepted{TaskID: taskID, Task: task})
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid worker RPC token")
+		return
+	}
+	expvar.Handler().ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// start runs the task in the background unless it's already running for the target
+func (s *Server) start(task string, fn TaskFunc, req TaskRequest, requestID string) (uuid.UUID, bool) {
+	key := task + ":" + req.TargetID.String()
+
+	s.mu.Lock()
+	if id, ok := s.inFlight[key]; ok {
+		s.mu.Unlock()
+		return id, false
+	}
+	taskID := uuid.New()
+	s.inFlight[key] = taskID
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.inFlight, key)
+			s.mu.Unlock()
+		}()
+		s.run(taskID, task, fn, req, requestID)
+	}()
+	return taskID, true
+}
+
+func (s *Server) run(taskID uuid.UUID, task string, fn TaskFunc, req TaskRequest, requestID string) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+	if requestID != "" {
+		ctx = correlation.WithID(ctx, requestID)
+	}
+
+	progress := Progress{TaskID: taskID, Task: task, TargetID: req.TargetID, Status: StatusRunning}
+	s.publish(ctx, req.UserID, progress)
+
+	start := time.Now()
+	result, err := fn(ctx, req, func(step string) {
+		progress.Step = step
+		s.publish(ctx, req.UserID, progress)
+	})
+
+	progress.Step = ""
+	if err != nil {
+		logger.Error("Worker task failed", "task", task, "taskId", taskID, "request_id", requestID, "error", err, "duration", time.Since(start))
+		progress.Status = StatusFailed
+		progress.Error = err.Error()
+	} else {
+		logger.Info("Worker task completed", "task", task, "taskId", taskID, "request_id", requestID, "duration", time.Since(start))
+		progress.Status = StatusCompleted
+		progress.Result = result
+	}
+	// The task's context may have run out; the outcome should still reach the user
+	s.publish(context.WithoutCancel(ctx), req.UserID, progress)
+}
+
+func (s *Server) publish(ctx context.Context, userID uuid.UUID, progress Progress) {
+	event, err := events.NewEvent(events.TypeTaskProgress, userID, progress)
+	if err == nil {
+		err = s.publisher.Publish(ctx, event)
+	}
+	if err != nil {
+		logger.Warn("Failed to publish task progress", "taskId", progress.TaskID, "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,182 @@
+package workerrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) progress(t *testing.T) []Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []Progress
+	for _, e := range p.events {
+		assert.Equal(t, events.TypeTaskProgress, e.Type)
+		var progress Progress
+		require.NoError(t, json.Unmarshal(e.Data, &progress))
+		out = append(out, progress)
+	}
+	return out
+}
+
+func TestServer_RunsTaskAndReportsProgress(t *testing.T) {
+	publisher := &recordingPublisher{}
+	server := NewServer(context.Background(), "secret", publisher)
+	server.Register(TaskSyncWallet, func(ctx context.Context, req TaskRequest, progress func(string)) (map[string]interface{}, error) {
+		progress("nft_transfers")
+		progress("derivative_positions")
+		return map[string]interface{}{"wallet": req.TargetID.String()}, nil
+	})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	userID, walletID := uuid.New(), uuid.New()
+	accepted, err := NewClient(ts.URL, "secret").SyncWallet(context.Background(), userID, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, TaskSyncWallet, accepted.Task)
+	server.Wait()
+
+	progress := publisher.progress(t)
+	require.Len(t, progress, 4)
+	assert.Equal(t, []string{"", "nft_transfers", "derivative_positions", ""},
+		[]string{progress[0].Step, progress[1].Step, progress[2].Step, progress[3].Step})
+	last := progress[3]
+	assert.Equal(t, accepted.TaskID, last.TaskID)
+	assert.Equal(t, walletID, last.TargetID)
+	assert.Equal(t, StatusCompleted, last.Status)
+	assert.Equal(t, walletID.String(), last.Result["wallet"])
+	for _, e := range publisher.events {
+		assert.Equal(t, userID, e.UserID)
+	}
+}
+
+func TestServer_PropagatesRequestID(t *testing.T) {
+	server := NewServer(context.Background(), "secret", &recordingPublisher{})
+	var requestID string
+	server.Register(TaskSyncWallet, func(ctx context.Context, req TaskRequest, progress func(string)) (map[string]interface{}, error) {
+		requestID = correlation.ID(ctx)
+		return nil, nil
+	})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	ctx := correlation.WithID(context.Background(), "3f2b6c1e-api-request")
+	_, err := NewClient(ts.URL, "secret").SyncWallet(ctx, uuid.New(), uuid.New())
+	require.NoError(t, err)
+	server.Wait()
+
+	assert.Equal(t, "3f2b6c1e-api-request", requestID)
+}
+
+func TestServer_ReportsFailure(t *testing.T) {
+	publisher := &recordingPublisher{}
+	server := NewServer(context.Background(), "secret", publisher)
+	server.Register(TaskEvaluateAlert, func(ctx context.Context, req TaskRequest, progress func(string)) (map[string]interface{}, error) {
+		return nil, errors.New("alert is disabled")
+	})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	_, err := NewClient(ts.URL, "secret").EvaluateAlert(context.Background(), uuid.New(), uuid.New())
+	require.NoError(t, err)
+	server.Wait()
+
+	progress := publisher.progress(t)
+	require.NotEmpty(t, progress)
+	assert.Equal(t, StatusFailed, progress[len(progress)-1].Status)
+	assert.Equal(t, "alert is disabled", progress[len(progress)-1].Error)
+}
+
+func TestServer_DoesNotStartTaskTwice(t *testing.T) {
+	release := make(chan struct{})
+	calls := 0
+	server := NewServer(context.Background(), "secret", &recordingPublisher{})
+	server.Register(TaskSyncWallet, func(ctx context.Context, req TaskRequest, progress func(string)) (map[string]interface{}, error) {
+		calls++
+		<-release
+		return nil, nil
+	})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	client := NewClient(ts.URL, "secret")
+	walletID := uuid.New()
+	first, err := client.SyncWallet(context.Background(), uuid.New(), walletID)
+	require.NoError(t, err)
+	second, err := client.SyncWallet(context.Background(), uuid.New(), walletID)
+	require.NoError(t, err)
+	assert.Equal(t, first.TaskID, second.TaskID)
+
+	close(release)
+	server.Wait()
+	assert.Equal(t, 1, calls)
+}
+
+func TestServer_RejectsBadRequests(t *testing.T) {
+	server := NewServer(context.Background(), "secret", &recordingPublisher{})
+	server.Register(TaskSyncWallet, func(ctx context.Context, req TaskRequest, progress func(string)) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	_, err := NewClient(ts.URL, "wrong").SyncWallet(context.Background(), uuid.New(), uuid.New())
+	assert.ErrorContains(t, err, "401")
+
+	_, err = NewClient(ts.URL, "secret").Run(context.Background(), "rebuild_everything", TaskRequest{UserID: uuid.New(), TargetID: uuid.New()})
+	assert.ErrorContains(t, err, "404")
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/tasks/"+TaskSyncWallet, strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_ServesMetrics(t *testing.T) {
+	ts := httptest.NewServer(NewServer(context.Background(), "secret", &recordingPublisher{}).Handler())
+	defer ts.Close()
+
+	get := func(token string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/metrics", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("wrong")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = get("secret")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var vars map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&vars))
+	assert.Contains(t, vars, "memstats")
+}
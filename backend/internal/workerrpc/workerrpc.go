@@ -0,0 +1,46 @@
+// Package workerrpc lets the API ask the worker to do work immediately instead of
+// waiting for the next cron tick. The worker serves a small JSON-over-HTTP surface on an
+// internal address, authenticated with a shared token; tasks run in the background and
+// report progress to the requesting user as realtime events.
+package workerrpc
+
+import (
+	"github.com/google/uuid"
+)
+
+// Tasks the worker runs on request
+const (
+	TaskSyncWallet    = "sync_wallet"
+	TaskEvaluateAlert = "evaluate_alert"
+)
+
+// Task statuses carried by progress events
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// TaskRequest asks the worker to run a task for a user against one target, such as
+// the wallet to sync or the alert to evaluate
+type TaskRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	TargetID uuid.UUID `json:"target_id"`
+}
+
+// TaskAccepted is the worker's reply once a task is queued
+type TaskAccepted struct {
+	TaskID uuid.UUID `json:"task_id"`
+	Task   string    `json:"task"`
+}
+
+// Progress is the data of a task progress event
+type Progress struct {
+	TaskID   uuid.UUID              `json:"task_id"`
+	Task     string                 `json:"task"`
+	TargetID uuid.UUID              `json:"target_id"`
+	Status   string                 `json:"status"`
+	Step     string                 `json:"step,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Result   map[string]interface{} `json:"result,omitempty"`
+}
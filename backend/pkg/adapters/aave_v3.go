@@ -0,0 +1,224 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+)
+
+func init() {
+	Register(aaveV3{})
+}
+
+var (
+	selGetReservesList     = Selector("getReservesList()")
+	selGetReserveData      = Selector("getReserveData(address)")
+	selBalanceOf           = Selector("balanceOf(address)")
+	selGetAllUserRewards   = Selector("getAllUserRewards(address[],address)")
+	selApprove             = Selector("approve(address,uint256)")
+	selAaveRepay           = Selector("repay(address,uint256,uint256,address)")
+	aaveVariableRateMode   = big.NewInt(2)
+	aaveRepayBufferDivisor = big.NewInt(1000) // approve 0.1% over the debt for interest accrued before the repay lands
+)
+
+// Words of the ReserveData struct getReserveData returns
+const (
+	reserveDataAToken       = 8
+	reserveDataVariableDebt = 10
+)
+
+// Details keys of Aave v3 positions
+const (
+	aaveDetailAsset        = "asset"
+	aaveDetailReceiptToken = "receipt_token"
+)
+
+// aaveV3 reads supplies and variable-rate borrows from the chain's Pool. Each reserve a
+// wallet supplies or borrows is a position, identified by its aToken or debt token.
+type aaveV3 struct{}
+
+func (aaveV3) Protocol() string { return blockchain.PositionProtocolAaveV3 }
+
+func (aaveV3) Chains() []int {
+	return []int{blockchain.ChainIDEthereum, blockchain.ChainIDPolygon, blockchain.ChainIDArbitrum, blockchain.ChainIDOptimism}
+}
+
+func (a aaveV3) DetectPositions(ctx context.Context, chain Chain, chainID int, owner string) ([]*Position, error) {
+	pool, ok := blockchain.AaveV3Pool(chainID)
+	if !ok {
+		return nil, fmt.Errorf("aave v3 is not deployed on chain %d", chainID)
+	}
+
+	out, err := chain.CallContract(ctx, chainID, "", pool, selGetReservesList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 reserves: %w", err)
+	}
+	reserves, err := DecodeAddressArray(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode aave v3 reserves: %w", err)
+	}
+	if len(reserves) == 0 {
+		return nil, nil
+	}
+
+	calls := make([]blockchain.Call, len(reserves))
+	for i, reserve := range reserves {
+		calls[i] = blockchain.Call{Target: pool, Data: EncodeCall(selGetReserveData, EncodeAddress(reserve))}
+	}
+	results, err := chain.Multicall(ctx, chainID, calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 reserve data: %w", err)
+	}
+
+	// Two balances per reserve: the aToken, then the variable debt token
+	type reserveTokens struct{ asset, aToken, debtToken string }
+	var tokens []reserveTokens
+	calls = calls[:0]
+	for i, reserve := range reserves {
+		if !results[i].Success || len(results[i].ReturnData) < (reserveDataVariableDebt+1)*32 {
+			continue
+		}
+		t := reserveTokens{
+			asset:     reserve,
+			aToken:    WordAddress(results[i].ReturnData, reserveDataAToken),
+			debtToken: WordAddress(results[i].ReturnData, reserveDataVariableDebt),
+		}
+		tokens = append(tokens, t)
+		calls = append(calls,
+			blockchain.Call{Target: t.aToken, Data: EncodeCall(selBalanceOf, EncodeAddress(owner))},
+			blockchain.Call{Target: t.debtToken, Data: EncodeCall(selBalanceOf, EncodeAddress(owner))})
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	if results, err = chain.Multicall(ctx, chainID, calls); err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 balances: %w", err)
+	}
+
+	type held struct {
+		asset, receipt, kind string
+		amount               *big.Int
+	}
+	var holdings []held
+	var assets []string
+	for i, t := range tokens {
+		supplied, borrowed := uintResult(results[2*i]), uintResult(results[2*i+1])
+		if supplied != nil && supplied.Sign() > 0 {
+			holdings = append(holdings, held{t.asset, t.aToken, PositionKindSupply, supplied})
+		}
+		if borrowed != nil && borrowed.Sign() > 0 {
+			holdings = append(holdings, held{t.asset, t.debtToken, PositionKindBorrow, borrowed})
+		}
+		if (supplied != nil && supplied.Sign() > 0) || (borrowed != nil && borrowed.Sign() > 0) {
+			assets = append(assets, t.asset)
+		}
+	}
+	if len(holdings) == 0 {
+		return nil, nil
+	}
+
+	metadata, err := chain.GetTokenMetadata(ctx, chainID, assets)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*Position, 0, len(holdings))
+	for _, h := range holdings {
+		meta, ok := metadata[strings.ToLower(h.asset)]
+		if !ok {
+			continue
+		}
+		positions = append(positions, &Position{
+			Protocol:      a.Protocol(),
+			ChainID:       chainID,
+			WalletAddress: owner,
+			Market:        pool,
+			ID:            h.receipt,
+			Kind:          h.kind,
+			// aTokens and debt tokens track the underlying one for one
+			Tokens: []TokenAmount{tokenAmount(h.asset, h.amount, meta)},
+			Details: map[string]string{
+				aaveDetailAsset:        h.asset,
+				aaveDetailReceiptToken: h.receipt,
+			},
+		})
+	}
+	return positions, nil
+}
+
+func (aaveV3) ValuePosition(ctx context.Context, chain Chain, position *Position) error {
+	value, err := ValueTokens(ctx, chain, position.Tokens)
+	if err != nil {
+		return err
+	}
+	position.ValueUSD = value
+	return nil
+}
+
+// PendingRewards reads the incentives accrued on the position's aToken or debt token
+func (aaveV3) PendingRewards(ctx context.Context, chain Chain, position *Position) ([]TokenAmount, error) {
+	controller, ok := blockchain.AaveV3RewardsController(position.ChainID)
+	if !ok {
+		return nil, nil
+	}
+
+	// getAllUserRewards(address[] assets, address user): the array's offset, the user,
+	// then the array
+	data := EncodeCall(selGetAllUserRewards,
+		EncodeUint(big.NewInt(64)), EncodeAddress(position.WalletAddress),
+		EncodeUint(big.NewInt(1)), EncodeAddress(position.Details[aaveDetailReceiptToken]))
+	out, err := chain.CallContract(ctx, position.ChainID, "", controller, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 rewards: %w", err)
+	}
+	rewardTokens, err := DecodeAddressArray(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode aave v3 rewards: %w", err)
+	}
+	amounts, err := DecodeUintArray(out, 1)
+	if err != nil || len(amounts) != len(rewardTokens) {
+		return nil, fmt.Errorf("failed to decode aave v3 reward amounts")
+	}
+
+	return earnedTokens(ctx, chain, position.ChainID, rewardTokens, amounts)
+}
+
+func (aaveV3) BuildClaimTx(_ context.Context, _ Chain, position *Position) ([]blockchain.PositionTx, error) {
+	return blockchain.BuildClaimPositionTxs(blockchain.PositionProtocolAaveV3, blockchain.PositionTxRequest{
+		ChainID: position.ChainID,
+		Owner:   position.WalletAddress,
+		Receipt: position.Details[aaveDetailReceiptToken],
+	})
+}
+
+// BuildExitTx withdraws a whole supply, or repays a whole borrow
+func (aaveV3) BuildExitTx(_ context.Context, _ Chain, position *Position) ([]blockchain.PositionTx, error) {
+	asset := position.Details[aaveDetailAsset]
+	if position.Kind == PositionKindSupply {
+		return blockchain.BuildExitPositionTxs(blockchain.PositionProtocolAaveV3, blockchain.PositionTxRequest{
+			ChainID: position.ChainID,
+			Asset:   asset,
+			Owner:   position.WalletAddress,
+		})
+	}
+
+	if len(position.Tokens) != 1 {
+		return nil, fmt.Errorf("aave v3 borrow has no debt amount")
+	}
+	debt, ok := new(big.Int).SetString(position.Tokens[0].AmountRaw, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid aave v3 debt amount %q", position.Tokens[0].AmountRaw)
+	}
+	allowance := new(big.Int).Add(debt, new(big.Int).Div(debt, aaveRepayBufferDivisor))
+	allowance.Add(allowance, big.NewInt(1))
+
+	// Repaying the maximum clears the debt as it stands when the transaction runs
+	return []blockchain.PositionTx{
+		NewTx(position.ChainID, asset, "Approve token", EncodeCall(selApprove, EncodeAddress(position.Market), EncodeUint(allowance))),
+		NewTx(position.ChainID, position.Market, "Repay Aave v3 debt", EncodeCall(selAaveRepay,
+			EncodeAddress(asset), EncodeUint(MaxUint256), EncodeUint(aaveVariableRateMode), EncodeAddress(position.WalletAddress))),
+	}, nil
+}
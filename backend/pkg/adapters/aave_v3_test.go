@@ -0,0 +1,123 @@
+package adapters
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOwner     = "0x1111111111111111111111111111111111111111"
+	testUSDC      = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	testWETH      = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	testAUSDC     = "0x98C23E9d8f34FEFb1B7BD6a91B7FF122F4e16F5c"
+	testDebtUSDC  = "0x72E95b8931767C79bA4EeE721354d6E99a61D004"
+	testAWETH     = "0x4d5F47FA6A74757f35C14fD3a6Ef8E3C9BC514E8"
+	testDebtWETH  = "0xeA51d7853EEFb32b6ee06b1C12E6dcCA88Be0fFE"
+	testRewardAAV = "0x7Fc66500c84A76Ad7e9c93437bFc5Ac33E2DDaE9"
+)
+
+// aaveFixture is a Pool with USDC and WETH reserves where the owner supplies 1,000 USDC
+// and borrows 0.5 WETH
+func aaveFixture() *fakeChain {
+	pool, _ := blockchain.AaveV3Pool(blockchain.ChainIDEthereum)
+	chain := newFakeChain()
+	chain.on(pool, "getReservesList()", func(string, []byte) []byte {
+		return words(uintWord(32), uintWord(2), EncodeAddress(testUSDC), EncodeAddress(testWETH))
+	})
+	reserveData := func(aToken, debtToken string) []byte {
+		data := make([]byte, 15*32)
+		copy(data[reserveDataAToken*32:], EncodeAddress(aToken))
+		copy(data[reserveDataVariableDebt*32:], EncodeAddress(debtToken))
+		return data
+	}
+	chain.on(pool, "getReserveData(address)", func(_ string, args []byte) []byte {
+		if strings.EqualFold(WordAddress(args, 0), testUSDC) {
+			return reserveData(testAUSDC, testDebtUSDC)
+		}
+		return reserveData(testAWETH, testDebtWETH)
+	})
+	balances := map[string]int64{testAUSDC: 1_000_000_000, testDebtUSDC: 0, testAWETH: 0, testDebtWETH: 500_000_000_000_000_000}
+	for token, balance := range balances {
+		balance := balance
+		chain.on(token, "balanceOf(address)", func(string, []byte) []byte { return uintWord(balance) })
+	}
+	chain.metadata[strings.ToLower(testUSDC)] = blockchain.TokenMetadata{Symbol: "USDC", Decimals: 6}
+	chain.metadata[strings.ToLower(testWETH)] = blockchain.TokenMetadata{Symbol: "WETH", Decimals: 18}
+	chain.metadata[strings.ToLower(testRewardAAV)] = blockchain.TokenMetadata{Symbol: "AAVE", Decimals: 18}
+	return chain
+}
+
+func TestAaveV3DetectPositions(t *testing.T) {
+	chain := aaveFixture()
+	positions, err := aaveV3{}.DetectPositions(context.Background(), chain, blockchain.ChainIDEthereum, testOwner)
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+
+	supply, borrow := positions[0], positions[1]
+	assert.Equal(t, PositionKindSupply, supply.Kind)
+	assert.Equal(t, testAUSDC, supply.ID)
+	assert.InDelta(t, 1000.0, supply.Tokens[0].Amount, 1e-9)
+	assert.Equal(t, "USDC", supply.Tokens[0].Symbol)
+
+	assert.Equal(t, PositionKindBorrow, borrow.Kind)
+	assert.Equal(t, testDebtWETH, borrow.ID)
+	assert.InDelta(t, 0.5, borrow.Tokens[0].Amount, 1e-12)
+
+	chain.prices["USDC"] = 1
+	require.NoError(t, aaveV3{}.ValuePosition(context.Background(), chain, supply))
+	require.NotNil(t, supply.ValueUSD)
+	assert.InDelta(t, 1000.0, *supply.ValueUSD, 1e-9)
+}
+
+func TestAaveV3PendingRewards(t *testing.T) {
+	chain := aaveFixture()
+	controller, _ := blockchain.AaveV3RewardsController(blockchain.ChainIDEthereum)
+	chain.on(controller, "getAllUserRewards(address[],address)", func(_ string, args []byte) []byte {
+		// The user follows the array's offset, and the array holds the aToken
+		assert.True(t, strings.EqualFold(WordAddress(args, 1), testOwner))
+		assert.True(t, strings.EqualFold(WordAddress(args, 3), testAUSDC))
+		earned, _ := new(big.Int).SetString("2500000000000000000", 10)
+		return words(uintWord(64), uintWord(160),
+			uintWord(2), EncodeAddress(testRewardAAV), EncodeAddress(testWETH),
+			uintWord(2), EncodeUint(earned), uintWord(0))
+	})
+
+	position := &Position{ChainID: blockchain.ChainIDEthereum, WalletAddress: testOwner, Details: map[string]string{aaveDetailReceiptToken: testAUSDC}}
+	rewards, err := aaveV3{}.PendingRewards(context.Background(), chain, position)
+	require.NoError(t, err)
+	require.Len(t, rewards, 1)
+	assert.Equal(t, "AAVE", rewards[0].Symbol)
+	assert.InDelta(t, 2.5, rewards[0].Amount, 1e-12)
+}
+
+func TestAaveV3Transactions(t *testing.T) {
+	chain := aaveFixture()
+	positions, err := aaveV3{}.DetectPositions(context.Background(), chain, blockchain.ChainIDEthereum, testOwner)
+	require.NoError(t, err)
+	supply, borrow := positions[0], positions[1]
+
+	txs, err := aaveV3{}.BuildClaimTx(context.Background(), chain, supply)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Contains(t, txs[0].Data, strings.ToLower(testAUSDC[2:]))
+
+	txs, err = aaveV3{}.BuildExitTx(context.Background(), chain, supply)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "Withdraw everything from Aave v3", txs[0].Description)
+
+	// Repaying approves the debt plus 0.1% and repays the maximum
+	txs, err = aaveV3{}.BuildExitTx(context.Background(), chain, borrow)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, strings.ToLower(testWETH), txs[0].To)
+	assert.True(t, strings.HasSuffix(txs[0].Data, big.NewInt(500_500_000_000_000_001).Text(16)))
+	assert.Equal(t, "0x573ade81", txs[1].Data[:10])
+	assert.Contains(t, txs[1].Data, strings.Repeat("f", 64))
+}
@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// MaxUint256 is the amount protocols read as "everything"
+	MaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	// MaxUint128 is the largest uint128, e.g. for collecting all Uniswap v3 fees
+	MaxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+	twoTo256 = new(big.Int).Lsh(big.NewInt(1), 256)
+	twoTo255 = new(big.Int).Lsh(big.NewInt(1), 255)
+
+	errShortResult = errors.New("call result too short")
+)
+
+// Selector returns the 4-byte selector of a function signature like "balanceOf(address)"
+func Selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// EncodeCall concatenates a selector and its static, already encoded arguments
+func EncodeCall(selector []byte, args ...[]byte) []byte {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	return data
+}
+
+// EncodeAddress encodes an address argument
+func EncodeAddress(address string) []byte {
+	return common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)
+}
+
+// EncodeUint encodes an unsigned integer argument
+func EncodeUint(v *big.Int) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+// WordUint returns the i-th 32-byte word of a result as an unsigned integer, or zero
+// when the result is shorter
+func WordUint(data []byte, i int) *big.Int {
+	if len(data) < (i+1)*32 {
+		return new(big.Int)
+	}
+	return new(big.Int).SetBytes(data[i*32 : (i+1)*32])
+}
+
+// WordInt returns the i-th word as a two's complement signed integer, as int24 ticks are
+func WordInt(data []byte, i int) *big.Int {
+	v := WordUint(data, i)
+	if v.Cmp(twoTo255) >= 0 {
+		v.Sub(v, twoTo256)
+	}
+	return v
+}
+
+// WordAddress returns the i-th word as a checksummed address
+func WordAddress(data []byte, i int) string {
+	if len(data) < (i+1)*32 {
+		return common.Address{}.Hex()
+	}
+	return common.BytesToAddress(data[i*32 : (i+1)*32]).Hex()
+}
+
+// DecodeAddressArray decodes the address[] whose offset is the i-th word
+func DecodeAddressArray(data []byte, i int) ([]string, error) {
+	words, err := arrayWords(data, i)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(words))
+	for j, word := range words {
+		addresses[j] = common.BytesToAddress(word).Hex()
+	}
+	return addresses, nil
+}
+
+// DecodeUintArray decodes the uint256[] whose offset is the i-th word
+func DecodeUintArray(data []byte, i int) ([]*big.Int, error) {
+	words, err := arrayWords(data, i)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*big.Int, len(words))
+	for j, word := range words {
+		values[j] = new(big.Int).SetBytes(word)
+	}
+	return values, nil
+}
+
+// arrayWords returns the elements of a dynamic array of static 32-byte values
+func arrayWords(data []byte, i int) ([][]byte, error) {
+	offset := WordUint(data, i)
+	if !offset.IsInt64() || offset.Int64()+32 > int64(len(data)) {
+		return nil, errShortResult
+	}
+	start := int(offset.Int64())
+	length := new(big.Int).SetBytes(data[start : start+32])
+	if !length.IsInt64() || start+32+int(length.Int64())*32 > len(data) {
+		return nil, errShortResult
+	}
+
+	words := make([][]byte, length.Int64())
+	for j := range words {
+		pos := start + 32 + j*32
+		words[j] = data[pos : pos+32]
+	}
+	return words, nil
+}
+
+// NewTx builds an unsigned transaction sending no native token
+func NewTx(chainID int, to, description string, data []byte) blockchain.PositionTx {
+	return blockchain.PositionTx{
+		ChainID:     chainID,
+		To:          strings.ToLower(to),
+		Data:        hexutil.Encode(data),
+		Value:       "0",
+		Description: description,
+	}
+}
+
+// tokenAmount describes raw units of a token using its metadata
+func tokenAmount(address string, raw *big.Int, meta blockchain.TokenMetadata) TokenAmount {
+	return TokenAmount{
+		Address:   address,
+		Symbol:    meta.Symbol,
+		Decimals:  meta.Decimals,
+		Amount:    scaleDown(raw, meta.Decimals),
+		AmountRaw: raw.String(),
+	}
+}
+
+func scaleDown(v *big.Int, decimals int) float64 {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(v), new(big.Float).SetInt(scale)).Float64()
+	return f
+}
+
+// earnedTokens describes reward amounts, leaving out rewards with nothing accrued
+func earnedTokens(ctx context.Context, chain Chain, chainID int, tokens []string, amounts []*big.Int) ([]TokenAmount, error) {
+	var earned []string
+	for i, token := range tokens {
+		if amounts[i].Sign() > 0 {
+			earned = append(earned, token)
+		}
+	}
+	if len(earned) == 0 {
+		return nil, nil
+	}
+
+	metadata, err := chain.GetTokenMetadata(ctx, chainID, earned)
+	if err != nil {
+		return nil, err
+	}
+	var rewards []TokenAmount
+	for i, token := range tokens {
+		meta, ok := metadata[strings.ToLower(token)]
+		if !ok || amounts[i].Sign() == 0 {
+			continue
+		}
+		rewards = append(rewards, tokenAmount(token, amounts[i], meta))
+	}
+	return rewards, nil
+}
+
+// uintResult returns the first word of a successful call, or nil
+func uintResult(result blockchain.CallResult) *big.Int {
+	if !result.Success || len(result.ReturnData) < 32 {
+		return nil
+	}
+	return WordUint(result.ReturnData, 0)
+}
@@ -0,0 +1,133 @@
+// Package adapters lets protocols plug their positions into the dashboard. An adapter
+// finds a wallet's positions in one protocol, values them, reports pending rewards and
+// builds the transactions claiming them or closing the position. The services only talk
+// to the registry, so adding a protocol needs no changes outside this package:
+//
+//  1. Add a file named after the protocol with a type implementing ProtocolAdapter. Read
+//     contracts through the Chain it's given, never a client of its own, so calls use the
+//     requesting user's provider keys.
+//  2. Register it from the file's init function under the slug the protocol has in the
+//     protocols table.
+//  3. Add a test reading from a fake Chain, as the Aave v3 and Uniswap v3 adapters do.
+//
+// The helpers in abi.go cover the encoding most adapters need.
+package adapters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+)
+
+// Position kinds
+const (
+	PositionKindSupply    = "supply"
+	PositionKindBorrow    = "borrow"
+	PositionKindLiquidity = "liquidity"
+	PositionKindStake     = "stake"
+)
+
+// ErrUnsupported is returned for operations an adapter doesn't offer for a position,
+// such as claiming from a protocol without rewards
+var ErrUnsupported = errors.New("not supported by this protocol")
+
+// Chain is the read access adapters get to a chain. BlockchainService implements it.
+type Chain interface {
+	// CallContract runs a read-only call; from may be empty
+	CallContract(ctx context.Context, chainID int, from, to string, data []byte) ([]byte, error)
+	// Multicall batches read-only calls; failed calls are reported per call
+	Multicall(ctx context.Context, chainID int, calls []blockchain.Call) ([]blockchain.CallResult, error)
+	// GetTokenMetadata returns token metadata keyed by lowercase address
+	GetTokenMetadata(ctx context.Context, chainID int, addresses []string) (map[string]blockchain.TokenMetadata, error)
+	// GetSymbolPricesUSD returns USD prices for the symbols it knows
+	GetSymbolPricesUSD(ctx context.Context, symbols []string) (map[string]float64, error)
+}
+
+// TokenAmount is an amount of one token held in, owed by or earned by a position
+type TokenAmount struct {
+	Address   string   `json:"address"`
+	Symbol    string   `json:"symbol"`
+	Decimals  int      `json:"decimals"`
+	Amount    float64  `json:"amount"`
+	AmountRaw string   `json:"amount_raw"` // In base units
+	ValueUSD  *float64 `json:"value_usd,omitempty"`
+}
+
+// Position is a wallet's position in one protocol market
+type Position struct {
+	Protocol      string `json:"protocol"`
+	ChainID       int    `json:"chain_id"`
+	WalletAddress string `json:"wallet_address"`
+	// Market is the contract holding the position
+	Market string `json:"market"`
+	// ID tells apart a wallet's positions in the same market, e.g. a Uniswap v3 token ID
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Tokens are what the position holds, or owes for borrows
+	Tokens []TokenAmount `json:"tokens"`
+	// ValueUSD is the value of Tokens; borrows are positive like any other position
+	ValueUSD *float64      `json:"value_usd,omitempty"`
+	Rewards  []TokenAmount `json:"rewards,omitempty"`
+	// Details are protocol-specific values the adapter needs to build transactions
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// ProtocolAdapter integrates one protocol
+type ProtocolAdapter interface {
+	// Protocol is the protocol's slug in the protocols table
+	Protocol() string
+	// Chains are the chains the protocol can be read on
+	Chains() []int
+	// DetectPositions finds the owner's open positions on the chain, with token amounts
+	DetectPositions(ctx context.Context, chain Chain, chainID int, owner string) ([]*Position, error)
+	// ValuePosition sets the USD values of the position and its tokens where prices are known
+	ValuePosition(ctx context.Context, chain Chain, position *Position) error
+	// PendingRewards returns what the position has earned and not yet claimed
+	PendingRewards(ctx context.Context, chain Chain, position *Position) ([]TokenAmount, error)
+	// BuildClaimTx returns the unsigned transactions claiming the pending rewards
+	BuildClaimTx(ctx context.Context, chain Chain, position *Position) ([]blockchain.PositionTx, error)
+	// BuildExitTx returns the unsigned transactions closing the position
+	BuildExitTx(ctx context.Context, chain Chain, position *Position) ([]blockchain.PositionTx, error)
+}
+
+// SupportsChain reports whether the adapter can read the chain
+func SupportsChain(adapter ProtocolAdapter, chainID int) bool {
+	for _, id := range adapter.Chains() {
+		if id == chainID {
+			return true
+		}
+	}
+	return false
+}
+
+// ValueTokens prices tokens by symbol, setting each priced token's ValueUSD, and returns
+// their total, or nil when none could be priced
+func ValueTokens(ctx context.Context, chain Chain, tokens []TokenAmount) (*float64, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	symbols := make([]string, len(tokens))
+	for i, token := range tokens {
+		symbols[i] = token.Symbol
+	}
+	prices, err := chain.GetSymbolPricesUSD(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	var total *float64
+	for i := range tokens {
+		price, ok := prices[tokens[i].Symbol]
+		if !ok {
+			continue
+		}
+		value := tokens[i].Amount * price
+		tokens[i].ValueUSD = &value
+		if total == nil {
+			total = new(float64)
+		}
+		*total += value
+	}
+	return total, nil
+}
@@ -0,0 +1,143 @@
+package adapters
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain answers calls from handlers keyed by lowercase contract address and
+// function signature
+type fakeChain struct {
+	handlers map[string]func(from string, args []byte) []byte
+	metadata map[string]blockchain.TokenMetadata
+	prices   map[string]float64
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		handlers: make(map[string]func(string, []byte) []byte),
+		metadata: make(map[string]blockchain.TokenMetadata),
+		prices:   make(map[string]float64),
+	}
+}
+
+func (f *fakeChain) on(contract, signature string, handler func(from string, args []byte) []byte) {
+	f.handlers[strings.ToLower(contract)+hex.EncodeToString(Selector(signature))] = handler
+}
+
+func (f *fakeChain) CallContract(_ context.Context, _ int, from, to string, data []byte) ([]byte, error) {
+	handler, ok := f.handlers[strings.ToLower(to)+hex.EncodeToString(data[:4])]
+	if !ok {
+		return nil, fmt.Errorf("execution reverted")
+	}
+	return handler(from, data[4:]), nil
+}
+
+func (f *fakeChain) Multicall(ctx context.Context, chainID int, calls []blockchain.Call) ([]blockchain.CallResult, error) {
+	results := make([]blockchain.CallResult, len(calls))
+	for i, call := range calls {
+		out, err := f.CallContract(ctx, chainID, "", call.Target, call.Data)
+		results[i] = blockchain.CallResult{Success: err == nil, ReturnData: out}
+	}
+	return results, nil
+}
+
+func (f *fakeChain) GetTokenMetadata(_ context.Context, _ int, addresses []string) (map[string]blockchain.TokenMetadata, error) {
+	found := make(map[string]blockchain.TokenMetadata)
+	for _, address := range addresses {
+		if meta, ok := f.metadata[strings.ToLower(address)]; ok {
+			found[strings.ToLower(address)] = meta
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeChain) GetSymbolPricesUSD(_ context.Context, symbols []string) (map[string]float64, error) {
+	found := make(map[string]float64)
+	for _, symbol := range symbols {
+		if price, ok := f.prices[symbol]; ok {
+			found[symbol] = price
+		}
+	}
+	return found, nil
+}
+
+// words concatenates ABI words
+func words(values ...[]byte) []byte {
+	var out []byte
+	for _, v := range values {
+		out = append(out, v...)
+	}
+	return out
+}
+
+func uintWord(v int64) []byte { return EncodeUint(big.NewInt(v)) }
+
+func TestRegistry(t *testing.T) {
+	var protocols []string
+	for _, adapter := range All() {
+		protocols = append(protocols, adapter.Protocol())
+	}
+	assert.Equal(t, []string{"aave-v3", "uniswap-v3"}, protocols)
+
+	adapter, ok := Get("aave-v3")
+	require.True(t, ok)
+	assert.True(t, SupportsChain(adapter, blockchain.ChainIDArbitrum))
+	assert.False(t, SupportsChain(adapter, 56))
+
+	_, ok = Get("curve")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { Register(aaveV3{}) })
+}
+
+func TestDecodeArrays(t *testing.T) {
+	// (address[] a, uint256[] b) with a = [0x11..11], b = [7, 9]
+	data := words(uintWord(64), uintWord(128),
+		uintWord(1), EncodeAddress("0x1111111111111111111111111111111111111111"),
+		uintWord(2), uintWord(7), uintWord(9))
+
+	addresses, err := DecodeAddressArray(data, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x1111111111111111111111111111111111111111"}, addresses)
+
+	values, err := DecodeUintArray(data, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(7), big.NewInt(9)}, values)
+
+	_, err = DecodeUintArray(data[:200], 1)
+	assert.Error(t, err)
+}
+
+func TestWordInt(t *testing.T) {
+	negative := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(887220))
+	data := words(EncodeUint(negative), uintWord(60))
+	assert.Equal(t, int64(-887220), WordInt(data, 0).Int64())
+	assert.Equal(t, int64(60), WordInt(data, 1).Int64())
+}
+
+func TestValueTokens(t *testing.T) {
+	chain := newFakeChain()
+	chain.prices["USDC"] = 1
+	chain.prices["WETH"] = 2000
+	tokens := []TokenAmount{{Symbol: "USDC", Amount: 500}, {Symbol: "WETH", Amount: 0.5}, {Symbol: "UNKNOWN", Amount: 3}}
+
+	total, err := ValueTokens(context.Background(), chain, tokens)
+	require.NoError(t, err)
+	require.NotNil(t, total)
+	assert.InDelta(t, 1500.0, *total, 1e-9)
+	assert.InDelta(t, 1000.0, *tokens[1].ValueUSD, 1e-9)
+	assert.Nil(t, tokens[2].ValueUSD)
+
+	total, err = ValueTokens(context.Background(), chain, []TokenAmount{{Symbol: "UNKNOWN"}})
+	require.NoError(t, err)
+	assert.Nil(t, total)
+}
@@ -0,0 +1,46 @@
+package adapters
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var registry = struct {
+	sync.RWMutex
+	adapters map[string]ProtocolAdapter
+}{adapters: make(map[string]ProtocolAdapter)}
+
+// Register makes an adapter available under its protocol slug. It is meant for init
+// functions and panics when the slug is already taken.
+func Register(adapter ProtocolAdapter) {
+	registry.Lock()
+	defer registry.Unlock()
+	protocol := adapter.Protocol()
+	if _, taken := registry.adapters[protocol]; taken {
+		panic(fmt.Sprintf("adapters: protocol %q registered twice", protocol))
+	}
+	registry.adapters[protocol] = adapter
+}
+
+// Get returns the adapter registered for the protocol
+func Get(protocol string) (ProtocolAdapter, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	adapter, ok := registry.adapters[protocol]
+	return adapter, ok
+}
+
+// All returns the registered adapters ordered by protocol
+func All() []ProtocolAdapter {
+	registry.RLock()
+	defer registry.RUnlock()
+	adapters := make([]ProtocolAdapter, 0, len(registry.adapters))
+	for _, adapter := range registry.adapters {
+		adapters = append(adapters, adapter)
+	}
+	sort.Slice(adapters, func(i, j int) bool {
+		return adapters[i].Protocol() < adapters[j].Protocol()
+	})
+	return adapters
+}
@@ -0,0 +1,329 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+)
+
+func init() {
+	Register(uniswapV3{})
+}
+
+const (
+	// uniswapV3PositionManager and uniswapV3Factory share their addresses across chains
+	uniswapV3PositionManager = "0xC36442b4a4522E871399CD717aBDD847Ab11FE88"
+	uniswapV3Factory         = "0x1F98431c8aD98523631AE4a59f267346ea31F984"
+	// maxUniswapV3Positions caps the position NFTs read per wallet
+	maxUniswapV3Positions = 100
+	// uniswapV3ExitSlippageBps is how far below the current amounts an exit may settle
+	uniswapV3ExitSlippageBps = 100
+	// uniswapV3ExitDeadline is how long an exit transaction stays valid after it's built
+	uniswapV3ExitDeadline = 20 * time.Minute
+)
+
+var (
+	selTokenOfOwnerByIndex = Selector("tokenOfOwnerByIndex(address,uint256)")
+	selPositions           = Selector("positions(uint256)")
+	selGetPool             = Selector("getPool(address,address,uint24)")
+	selSlot0               = Selector("slot0()")
+	selCollect             = Selector("collect((uint256,address,uint128,uint128))")
+	selDecreaseLiquidity   = Selector("decreaseLiquidity((uint256,uint128,uint256,uint256,uint256))")
+
+	// now is replaced in tests
+	now = time.Now
+)
+
+// Words of the positions(uint256) result
+const (
+	positionToken0    = 2
+	positionToken1    = 3
+	positionFee       = 4
+	positionTickLower = 5
+	positionTickUpper = 6
+	positionLiquidity = 7
+	positionOwed0     = 10
+	positionOwed1     = 11
+)
+
+// Details keys of Uniswap v3 positions
+const (
+	uniswapDetailPool      = "pool"
+	uniswapDetailFee       = "fee"
+	uniswapDetailTickLower = "tick_lower"
+	uniswapDetailTickUpper = "tick_upper"
+	uniswapDetailLiquidity = "liquidity"
+	uniswapDetailInRange   = "in_range"
+)
+
+// uniswapV3 reads liquidity positions, one per position NFT the wallet holds
+type uniswapV3 struct{}
+
+func (uniswapV3) Protocol() string { return "uniswap-v3" }
+
+func (uniswapV3) Chains() []int {
+	return []int{blockchain.ChainIDEthereum, blockchain.ChainIDPolygon, blockchain.ChainIDArbitrum, blockchain.ChainIDOptimism}
+}
+
+// uniswapV3NFT is a position NFT as positions(uint256) describes it
+type uniswapV3NFT struct {
+	id                   *big.Int
+	token0, token1       string
+	fee                  int64
+	tickLower, tickUpper int64
+	liquidity            *big.Int
+}
+
+func (u uniswapV3) DetectPositions(ctx context.Context, chain Chain, chainID int, owner string) ([]*Position, error) {
+	out, err := chain.CallContract(ctx, chainID, "", uniswapV3PositionManager, EncodeCall(selBalanceOf, EncodeAddress(owner)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uniswap v3 positions: %w", err)
+	}
+	count := WordUint(out, 0)
+	if count.Sign() == 0 {
+		return nil, nil
+	}
+	n := maxUniswapV3Positions
+	if count.IsInt64() && count.Int64() < int64(n) {
+		n = int(count.Int64())
+	}
+
+	calls := make([]blockchain.Call, n)
+	for i := range calls {
+		calls[i] = blockchain.Call{Target: uniswapV3PositionManager, Data: EncodeCall(selTokenOfOwnerByIndex, EncodeAddress(owner), EncodeUint(big.NewInt(int64(i))))}
+	}
+	results, err := chain.Multicall(ctx, chainID, calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uniswap v3 token IDs: %w", err)
+	}
+	calls = calls[:0]
+	for _, result := range results {
+		if id := uintResult(result); id != nil {
+			calls = append(calls, blockchain.Call{Target: uniswapV3PositionManager, Data: EncodeCall(selPositions, EncodeUint(id))})
+		}
+	}
+	if results, err = chain.Multicall(ctx, chainID, calls); err != nil {
+		return nil, fmt.Errorf("failed to read uniswap v3 positions: %w", err)
+	}
+
+	// Closed positions keep their NFT; only those with liquidity or uncollected tokens count
+	var nfts []uniswapV3NFT
+	pools := make(map[string]string)
+	var poolCalls []blockchain.Call
+	var poolKeys []string
+	for i, result := range results {
+		data := result.ReturnData
+		if !result.Success || len(data) < (positionOwed1+1)*32 {
+			continue
+		}
+		nft := uniswapV3NFT{
+			id:        new(big.Int).SetBytes(calls[i].Data[4:36]),
+			token0:    WordAddress(data, positionToken0),
+			token1:    WordAddress(data, positionToken1),
+			fee:       WordUint(data, positionFee).Int64(),
+			tickLower: WordInt(data, positionTickLower).Int64(),
+			tickUpper: WordInt(data, positionTickUpper).Int64(),
+			liquidity: WordUint(data, positionLiquidity),
+		}
+		if nft.liquidity.Sign() == 0 && WordUint(data, positionOwed0).Sign() == 0 && WordUint(data, positionOwed1).Sign() == 0 {
+			continue
+		}
+		nfts = append(nfts, nft)
+
+		key := poolKey(nft)
+		if _, seen := pools[key]; !seen {
+			pools[key] = ""
+			poolKeys = append(poolKeys, key)
+			poolCalls = append(poolCalls, blockchain.Call{Target: uniswapV3Factory, Data: EncodeCall(selGetPool,
+				EncodeAddress(nft.token0), EncodeAddress(nft.token1), EncodeUint(big.NewInt(nft.fee)))})
+		}
+	}
+	if len(nfts) == 0 {
+		return nil, nil
+	}
+
+	if results, err = chain.Multicall(ctx, chainID, poolCalls); err != nil {
+		return nil, fmt.Errorf("failed to read uniswap v3 pools: %w", err)
+	}
+	slotCalls := make([]blockchain.Call, 0, len(poolKeys))
+	for i, key := range poolKeys {
+		if results[i].Success && len(results[i].ReturnData) >= 32 {
+			pools[key] = WordAddress(results[i].ReturnData, 0)
+		}
+		slotCalls = append(slotCalls, blockchain.Call{Target: pools[key], Data: selSlot0})
+	}
+	if results, err = chain.Multicall(ctx, chainID, slotCalls); err != nil {
+		return nil, fmt.Errorf("failed to read uniswap v3 prices: %w", err)
+	}
+	sqrtPrices := make(map[string]*big.Int)
+	var tokens []string
+	for i, key := range poolKeys {
+		if price := uintResult(results[i]); price != nil && price.Sign() > 0 {
+			sqrtPrices[key] = price
+		}
+	}
+	for _, nft := range nfts {
+		tokens = append(tokens, nft.token0, nft.token1)
+	}
+
+	metadata, err := chain.GetTokenMetadata(ctx, chainID, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*Position, 0, len(nfts))
+	for _, nft := range nfts {
+		key := poolKey(nft)
+		sqrtPrice, priced := sqrtPrices[key]
+		meta0, ok0 := metadata[strings.ToLower(nft.token0)]
+		meta1, ok1 := metadata[strings.ToLower(nft.token1)]
+		if !priced || !ok0 || !ok1 {
+			continue
+		}
+		amount0, amount1 := liquidityAmounts(nft.liquidity, sqrtPrice, nft.tickLower, nft.tickUpper)
+		inRange := amount0.Sign() > 0 && amount1.Sign() > 0
+
+		positions = append(positions, &Position{
+			Protocol:      u.Protocol(),
+			ChainID:       chainID,
+			WalletAddress: owner,
+			Market:        uniswapV3PositionManager,
+			ID:            nft.id.String(),
+			Kind:          PositionKindLiquidity,
+			Tokens:        []TokenAmount{tokenAmount(nft.token0, amount0, meta0), tokenAmount(nft.token1, amount1, meta1)},
+			Details: map[string]string{
+				uniswapDetailPool:      pools[key],
+				uniswapDetailFee:       strconv.FormatInt(nft.fee, 10),
+				uniswapDetailTickLower: strconv.FormatInt(nft.tickLower, 10),
+				uniswapDetailTickUpper: strconv.FormatInt(nft.tickUpper, 10),
+				uniswapDetailLiquidity: nft.liquidity.String(),
+				uniswapDetailInRange:   strconv.FormatBool(inRange),
+			},
+		})
+	}
+	return positions, nil
+}
+
+func (uniswapV3) ValuePosition(ctx context.Context, chain Chain, position *Position) error {
+	value, err := ValueTokens(ctx, chain, position.Tokens)
+	if err != nil {
+		return err
+	}
+	position.ValueUSD = value
+	return nil
+}
+
+// PendingRewards simulates collecting as the owner, which returns the swap fees earned,
+// including those the pool hasn't credited to the position yet
+func (uniswapV3) PendingRewards(ctx context.Context, chain Chain, position *Position) ([]TokenAmount, error) {
+	tokenID, err := uniswapV3TokenID(position)
+	if err != nil {
+		return nil, err
+	}
+	if len(position.Tokens) != 2 {
+		return nil, fmt.Errorf("uniswap v3 position has no tokens")
+	}
+
+	out, err := chain.CallContract(ctx, position.ChainID, position.WalletAddress, uniswapV3PositionManager, collectCall(tokenID, position.WalletAddress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uniswap v3 fees: %w", err)
+	}
+	return earnedTokens(ctx, chain, position.ChainID,
+		[]string{position.Tokens[0].Address, position.Tokens[1].Address},
+		[]*big.Int{WordUint(out, 0), WordUint(out, 1)})
+}
+
+func (uniswapV3) BuildClaimTx(_ context.Context, _ Chain, position *Position) ([]blockchain.PositionTx, error) {
+	tokenID, err := uniswapV3TokenID(position)
+	if err != nil {
+		return nil, err
+	}
+	return []blockchain.PositionTx{
+		NewTx(position.ChainID, uniswapV3PositionManager, "Collect Uniswap v3 fees", collectCall(tokenID, position.WalletAddress)),
+	}, nil
+}
+
+// BuildExitTx removes all the position's liquidity, accepting up to 1% less than its
+// current amounts, then collects the tokens and fees
+func (uniswapV3) BuildExitTx(_ context.Context, _ Chain, position *Position) ([]blockchain.PositionTx, error) {
+	tokenID, err := uniswapV3TokenID(position)
+	if err != nil {
+		return nil, err
+	}
+	liquidity, ok := new(big.Int).SetString(position.Details[uniswapDetailLiquidity], 10)
+	if !ok {
+		return nil, fmt.Errorf("uniswap v3 position has no liquidity amount")
+	}
+
+	txs := make([]blockchain.PositionTx, 0, 2)
+	if liquidity.Sign() > 0 {
+		if len(position.Tokens) != 2 {
+			return nil, fmt.Errorf("uniswap v3 position has no tokens")
+		}
+		mins := make([]*big.Int, 2)
+		for i, token := range position.Tokens {
+			amount, ok := new(big.Int).SetString(token.AmountRaw, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid uniswap v3 token amount %q", token.AmountRaw)
+			}
+			mins[i] = amount.Mul(amount, big.NewInt(10000-uniswapV3ExitSlippageBps))
+			mins[i].Div(mins[i], big.NewInt(10000))
+		}
+		deadline := big.NewInt(now().Add(uniswapV3ExitDeadline).Unix())
+		txs = append(txs, NewTx(position.ChainID, uniswapV3PositionManager, "Remove Uniswap v3 liquidity", EncodeCall(selDecreaseLiquidity,
+			EncodeUint(tokenID), EncodeUint(liquidity), EncodeUint(mins[0]), EncodeUint(mins[1]), EncodeUint(deadline))))
+	}
+	return append(txs, NewTx(position.ChainID, uniswapV3PositionManager, "Collect Uniswap v3 tokens and fees", collectCall(tokenID, position.WalletAddress))), nil
+}
+
+// collectCall collects everything owed to the position to recipient
+func collectCall(tokenID *big.Int, recipient string) []byte {
+	return EncodeCall(selCollect, EncodeUint(tokenID), EncodeAddress(recipient), EncodeUint(MaxUint128), EncodeUint(MaxUint128))
+}
+
+func uniswapV3TokenID(position *Position) (*big.Int, error) {
+	tokenID, ok := new(big.Int).SetString(position.ID, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid uniswap v3 token ID %q", position.ID)
+	}
+	return tokenID, nil
+}
+
+func poolKey(nft uniswapV3NFT) string {
+	return strings.ToLower(nft.token0+nft.token1) + strconv.FormatInt(nft.fee, 10)
+}
+
+// liquidityAmounts returns the token amounts, in base units, that liquidity between the
+// two ticks holds at the pool's current price. Float math makes them estimates good to
+// about 15 significant digits, plenty for valuation.
+func liquidityAmounts(liquidity, sqrtPriceX96 *big.Int, tickLower, tickUpper int64) (*big.Int, *big.Int) {
+	l, _ := new(big.Float).SetInt(liquidity).Float64()
+	q96, _ := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96)).Float64()
+	price, _ := new(big.Float).SetInt(sqrtPriceX96).Float64()
+	sp := price / q96
+	sa := math.Pow(1.0001, float64(tickLower)/2)
+	sb := math.Pow(1.0001, float64(tickUpper)/2)
+
+	var amount0, amount1 float64
+	switch {
+	case sp <= sa:
+		amount0 = l * (sb - sa) / (sa * sb)
+	case sp >= sb:
+		amount1 = l * (sb - sa)
+	default:
+		amount0 = l * (sb - sp) / (sp * sb)
+		amount1 = l * (sp - sa)
+	}
+	return floatToInt(amount0), floatToInt(amount1)
+}
+
+func floatToInt(v float64) *big.Int {
+	i, _ := big.NewFloat(math.Floor(v)).Int(nil)
+	return i
+}
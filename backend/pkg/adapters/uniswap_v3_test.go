@@ -0,0 +1,132 @@
+package adapters
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUniswapPool = "0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"
+
+var q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+// uniswapFixture has the owner holding two position NFTs in a USDC/WETH pool priced at
+// tick 0: #100 with liquidity between ticks -60 and 60, and #101, closed
+func uniswapFixture() *fakeChain {
+	chain := newFakeChain()
+	chain.on(uniswapV3PositionManager, "balanceOf(address)", func(string, []byte) []byte { return uintWord(2) })
+	chain.on(uniswapV3PositionManager, "tokenOfOwnerByIndex(address,uint256)", func(_ string, args []byte) []byte {
+		return uintWord(100 + WordUint(args, 1).Int64())
+	})
+	chain.on(uniswapV3PositionManager, "positions(uint256)", func(_ string, args []byte) []byte {
+		data := make([]byte, 12*32)
+		copy(data[positionToken0*32:], EncodeAddress(testUSDC))
+		copy(data[positionToken1*32:], EncodeAddress(testWETH))
+		copy(data[positionFee*32:], uintWord(500))
+		copy(data[positionTickLower*32:], EncodeUint(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(60))))
+		copy(data[positionTickUpper*32:], uintWord(60))
+		if WordUint(args, 0).Int64() == 100 {
+			copy(data[positionLiquidity*32:], uintWord(1_000_000_000_000))
+		}
+		return data
+	})
+	chain.on(uniswapV3Factory, "getPool(address,address,uint24)", func(string, []byte) []byte { return EncodeAddress(testUniswapPool) })
+	chain.on(testUniswapPool, "slot0()", func(string, []byte) []byte { return words(EncodeUint(q96), make([]byte, 6*32)) })
+	chain.metadata[strings.ToLower(testUSDC)] = blockchain.TokenMetadata{Symbol: "USDC", Decimals: 6}
+	chain.metadata[strings.ToLower(testWETH)] = blockchain.TokenMetadata{Symbol: "WETH", Decimals: 18}
+	return chain
+}
+
+func TestLiquidityAmounts(t *testing.T) {
+	liquidity := big.NewInt(1_000_000_000_000)
+	sa, sb := math.Pow(1.0001, -30), math.Pow(1.0001, 30)
+
+	// In range at tick 0 the position holds both tokens
+	amount0, amount1 := liquidityAmounts(liquidity, q96, -60, 60)
+	assert.InDelta(t, 1e12*(1-1/sb), float64(amount0.Int64()), 1)
+	assert.InDelta(t, 1e12*(1-sa), float64(amount1.Int64()), 1)
+
+	// Below the range it's all token0, above it all token1
+	amount0, amount1 = liquidityAmounts(liquidity, q96, 60, 120)
+	assert.Positive(t, amount0.Sign())
+	assert.Zero(t, amount1.Sign())
+	amount0, amount1 = liquidityAmounts(liquidity, q96, -120, -60)
+	assert.Zero(t, amount0.Sign())
+	assert.Positive(t, amount1.Sign())
+}
+
+func TestUniswapV3DetectPositions(t *testing.T) {
+	chain := uniswapFixture()
+	positions, err := uniswapV3{}.DetectPositions(context.Background(), chain, blockchain.ChainIDEthereum, testOwner)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+
+	position := positions[0]
+	assert.Equal(t, "100", position.ID)
+	assert.Equal(t, PositionKindLiquidity, position.Kind)
+	assert.Equal(t, testUniswapPool, position.Details[uniswapDetailPool])
+	assert.Equal(t, "-60", position.Details[uniswapDetailTickLower])
+	assert.Equal(t, "true", position.Details[uniswapDetailInRange])
+	require.Len(t, position.Tokens, 2)
+	assert.Equal(t, "USDC", position.Tokens[0].Symbol)
+	assert.Equal(t, "WETH", position.Tokens[1].Symbol)
+	assert.Positive(t, position.Tokens[0].Amount)
+}
+
+func TestUniswapV3PendingRewards(t *testing.T) {
+	chain := uniswapFixture()
+	chain.on(uniswapV3PositionManager, "collect((uint256,address,uint128,uint128))", func(from string, args []byte) []byte {
+		// collect only answers the position's owner
+		assert.Equal(t, testOwner, from)
+		assert.Equal(t, int64(100), WordUint(args, 0).Int64())
+		return words(uintWord(3_000_000), uintWord(0))
+	})
+	positions, err := uniswapV3{}.DetectPositions(context.Background(), chain, blockchain.ChainIDEthereum, testOwner)
+	require.NoError(t, err)
+
+	rewards, err := uniswapV3{}.PendingRewards(context.Background(), chain, positions[0])
+	require.NoError(t, err)
+	require.Len(t, rewards, 1)
+	assert.Equal(t, "USDC", rewards[0].Symbol)
+	assert.InDelta(t, 3.0, rewards[0].Amount, 1e-9)
+}
+
+func TestUniswapV3ExitTx(t *testing.T) {
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+
+	positions, err := uniswapV3{}.DetectPositions(context.Background(), uniswapFixture(), blockchain.ChainIDEthereum, testOwner)
+	require.NoError(t, err)
+	position := positions[0]
+
+	txs, err := uniswapV3{}.BuildExitTx(context.Background(), nil, position)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	decrease := txs[0]
+	assert.Equal(t, "0x0c49ccbe", decrease.Data[:10])
+	args := hexutil.MustDecode("0x" + decrease.Data[10:])
+	assert.Equal(t, int64(100), WordUint(args, 0).Int64())
+	assert.Equal(t, int64(1_000_000_000_000), WordUint(args, 1).Int64())
+	amount0, _ := new(big.Int).SetString(position.Tokens[0].AmountRaw, 10)
+	assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(amount0, big.NewInt(9900)), big.NewInt(10000)), WordUint(args, 2))
+	assert.Equal(t, int64(1_700_000_000+20*60), WordUint(args, 4).Int64())
+
+	collect := txs[1]
+	assert.Equal(t, "0xfc6f7865", collect.Data[:10])
+	assert.Equal(t, strings.ToLower(uniswapV3PositionManager), collect.To)
+
+	// A position without liquidity only collects
+	position.Details[uniswapDetailLiquidity] = "0"
+	txs, err = uniswapV3{}.BuildExitTx(context.Background(), nil, position)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+}
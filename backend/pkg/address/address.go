@@ -0,0 +1,76 @@
+// Package address normalizes EVM addresses. Addresses are stored and compared in
+// lowercase and shown to users in their EIP-55 checksummed form. Addresses of other
+// chains (bitcoin, bech32) are case-sensitive or case-fixed by their own encodings and
+// pass through unchanged.
+package address
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// IsEVM reports whether s is a 0x-prefixed 20-byte hex address, in any case
+func IsEVM(s string) bool {
+	if len(s) != 42 || (s[:2] != "0x" && s[:2] != "0X") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// Normalize returns the stored form of an address: EVM addresses lowercased, anything
+// else trimmed but otherwise unchanged
+func Normalize(s string) string {
+	s = strings.TrimSpace(s)
+	if IsEVM(s) {
+		return "0x" + strings.ToLower(s[2:])
+	}
+	return s
+}
+
+// Parse validates an EVM address and returns its stored form. Mixed-case input must carry
+// a valid EIP-55 checksum, so a mistyped checksummed address is caught rather than stored.
+func Parse(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !IsEVM(s) {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+	body := s[2:]
+	if body != strings.ToLower(body) && body != strings.ToUpper(body) && Checksum(s) != "0x"+body {
+		return "", fmt.Errorf("invalid checksum for address %s", s)
+	}
+	return Normalize(s), nil
+}
+
+// Checksum returns the EIP-55 form of an EVM address; other addresses are returned unchanged
+func Checksum(s string) string {
+	if !IsEVM(s) {
+		return s
+	}
+	lower := strings.ToLower(s[2:])
+
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	digest := hash.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		// Letters are uppercased where the matching nibble of the hash is 8 or more
+		nibble := digest[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if c >= 'a' && c <= 'f' && nibble&0x0f >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
+
+// Equal reports whether two addresses are the same, ignoring the case of EVM addresses
+func Equal(a, b string) bool {
+	return Normalize(a) == Normalize(b)
+}
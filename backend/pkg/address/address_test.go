@@ -0,0 +1,63 @@
+package address
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// EIP-55 test vectors
+var checksummed = []string{
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestChecksum(t *testing.T) {
+	for _, want := range checksummed {
+		assert.Equal(t, want, Checksum(strings.ToLower(want)))
+		assert.Equal(t, want, Checksum(strings.ToUpper(want[:2])+strings.ToUpper(want[2:])))
+	}
+
+	// Non-EVM addresses pass through
+	assert.Equal(t, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Checksum("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"))
+	assert.Equal(t, "native", Checksum("native"))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Normalize(" 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed "))
+	assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", Normalize("0X5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"))
+	assert.Equal(t, "cosmos1abc", Normalize("cosmos1abc"))
+	assert.Equal(t, "0x123", Normalize("0x123"))
+}
+
+func TestParse(t *testing.T) {
+	for _, addr := range checksummed {
+		got, err := Parse(addr)
+		require.NoError(t, err)
+		assert.Equal(t, strings.ToLower(addr), got)
+	}
+
+	// Single-case input carries no checksum
+	got, err := Parse("0xFB6916095CA1DF60BB79CE92CE3EA74C37C5D359")
+	require.NoError(t, err)
+	assert.Equal(t, "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359", got)
+
+	// A flipped letter breaks the checksum
+	_, err = Parse("0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	assert.Error(t, err)
+
+	for _, bad := range []string{"", "0x", "0x123", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeZ"} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+	assert.False(t, Equal("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"))
+	assert.False(t, Equal("bc1QAR", "bc1qar"))
+}
@@ -0,0 +1,174 @@
+// Package amounts converts between raw token amounts, integers in a token's smallest
+// unit as chains and providers report them, and amounts in whole tokens. Every
+// conversion goes through here so that a token's decimals are applied in one place:
+// reading a 6-decimal USDC balance with 18 decimals is off by a factor of 10^12.
+package amounts
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+)
+
+// Decimals of the native assets of each chain namespace
+const (
+	EVMNativeDecimals = 18 // ETH, MATIC and the other EVM gas tokens, in wei
+	BitcoinDecimals   = 8  // BTC, in satoshis
+	CosmosDecimals    = 6  // ATOM, OSMO and the other Cosmos staking denoms, in micro units
+)
+
+// MaxDecimals is the most decimals a token may declare. ERC-20 decimals is a uint8, and
+// no real token uses more than 77, the most a uint256 amount can carry.
+const MaxDecimals = 77
+
+// NativeDecimals returns the decimals of a chain's native asset
+func NativeDecimals(chain models.ChainRef) int {
+	switch chain.Namespace {
+	case models.ChainNamespaceBitcoin:
+		return BitcoinDecimals
+	case models.ChainNamespaceCosmos:
+		return CosmosDecimals
+	default:
+		return EVMNativeDecimals
+	}
+}
+
+func checkDecimals(decimals int) error {
+	if decimals < 0 || decimals > MaxDecimals {
+		return fmt.Errorf("invalid decimals %d", decimals)
+	}
+	return nil
+}
+
+func pow10(decimals int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+}
+
+// ParseBaseUnits reads a raw amount in base units, in decimal or as 0x-prefixed hex the
+// way JSON-RPC returns it. Raw amounts are whole and not negative.
+func ParseBaseUnits(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	var (
+		raw *big.Int
+		ok  bool
+	)
+	if hex := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"); hex != s {
+		raw, ok = new(big.Int).SetString(hex, 16)
+	} else {
+		raw, ok = new(big.Int).SetString(s, 10)
+	}
+	if !ok || raw.Sign() < 0 {
+		return nil, fmt.Errorf("invalid base unit amount %q", s)
+	}
+	return raw, nil
+}
+
+// ToUnits returns a raw amount in whole tokens, exactly: 1500000 with 6 decimals is 1.5.
+// Decimals out of range are treated as 0.
+func ToUnits(raw *big.Int, decimals int) decimal.Decimal {
+	if checkDecimals(decimals) != nil {
+		decimals = 0
+	}
+	return decimal.NewFromBigInt(raw, int32(decimals))
+}
+
+// FromUnits returns an amount in whole tokens as a raw amount. Amounts finer than the
+// token's smallest unit, or negative, can't be sent and are an error rather than rounded.
+func FromUnits(amount decimal.Decimal, decimals int) (*big.Int, error) {
+	if err := checkDecimals(decimals); err != nil {
+		return nil, err
+	}
+	if amount.Sign() < 0 {
+		return nil, fmt.Errorf("negative amount %s", amount)
+	}
+	scaled := amount.Mul(decimal.NewFromBigInt(pow10(decimals), 0))
+	if !scaled.Equal(scaled.Round(0)) {
+		return nil, fmt.Errorf("amount %s has more than %d decimal places", amount, decimals)
+	}
+	raw, _ := new(big.Int).SetString(scaled.StringFixed(0), 10)
+	return raw, nil
+}
+
+// ParseUnits reads an amount in whole tokens, such as "1.5", as a raw amount
+func ParseUnits(amount string, decimals int) (*big.Int, error) {
+	d, err := decimal.Parse(amount)
+	if err != nil {
+		return nil, err
+	}
+	return FromUnits(d, decimals)
+}
+
+// FormatUnits formats a raw amount in whole tokens without trailing zeros, e.g. "1.5"
+func FormatUnits(raw *big.Int, decimals int) string {
+	return ToUnits(raw, decimals).String()
+}
+
+// Float64 returns a raw amount in whole tokens as the nearest float64, for ratios and
+// display where exactness isn't needed
+func Float64(raw *big.Int, decimals int) float64 {
+	return ToUnits(raw, decimals).Float64()
+}
+
+// Rescale converts a raw amount between two decimals, such as a token bridged to a
+// chain where it has fewer. Precision lost scaling down is truncated, and exact reports
+// whether any was.
+func Rescale(raw *big.Int, from, to int) (scaled *big.Int, exact bool) {
+	switch {
+	case to == from:
+		return new(big.Int).Set(raw), true
+	case to > from:
+		return new(big.Int).Mul(raw, pow10(to-from)), true
+	default:
+		quotient, remainder := new(big.Int).QuoRem(raw, pow10(from-to), new(big.Int))
+		return quotient, remainder.Sign() == 0
+	}
+}
+
+// Percent returns part as a percentage of whole, such as a holder's share of a token's
+// supply; both are raw amounts of the same token. A zero whole has no shares and gives 0.
+func Percent(part, whole *big.Int) float64 {
+	if whole.Sign() == 0 {
+		return 0
+	}
+	pct, _ := new(big.Rat).SetFrac(new(big.Int).Mul(part, big.NewInt(100)), whole).Float64()
+	return pct
+}
+
+// USDValue returns the USD value of a raw amount at a price per whole token. The price
+// is the float64 providers quote; the multiplication itself is exact.
+func USDValue(raw *big.Int, decimals int, priceUSD float64) (decimal.Decimal, error) {
+	if err := checkDecimals(decimals); err != nil {
+		return decimal.Zero, err
+	}
+	if math.IsNaN(priceUSD) || math.IsInf(priceUSD, 0) || priceUSD < 0 {
+		return decimal.Zero, fmt.Errorf("invalid price %v", priceUSD)
+	}
+	return ToUnits(raw, decimals).Mul(decimal.NewFromFloat(priceUSD)), nil
+}
+
+// Display formats an amount in whole tokens for people: 2 decimal places from 1000, 4
+// from 1 and 6 below, and "<0.000001" (">-0.000001" when negative) for dust that
+// would otherwise show as 0
+func Display(amount decimal.Decimal) string {
+	if amount.IsZero() {
+		return "0"
+	}
+	abs := amount.Abs()
+	switch {
+	case abs.Cmp(decimal.NewFromInt(1000)) >= 0:
+		return amount.StringFixed(2)
+	case abs.Cmp(decimal.NewFromInt(1)) >= 0:
+		return amount.StringFixed(4)
+	case abs.Cmp(decimal.New(1, 6)) < 0:
+		if amount.Sign() < 0 {
+			return ">-0.000001"
+		}
+		return "<0.000001"
+	default:
+		return amount.StringFixed(6)
+	}
+}
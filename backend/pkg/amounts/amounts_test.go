@@ -0,0 +1,199 @@
+package amounts
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bigInt(t *testing.T, s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	require.True(t, ok, s)
+	return n
+}
+
+func TestNativeDecimals(t *testing.T) {
+	assert.Equal(t, 18, NativeDecimals(models.EVMChain(1)))
+	assert.Equal(t, 18, NativeDecimals(models.EVMChain(137)))
+	assert.Equal(t, 8, NativeDecimals(models.BitcoinChain))
+	assert.Equal(t, 6, NativeDecimals(models.ChainRef{Namespace: models.ChainNamespaceCosmos, Reference: "cosmoshub-4"}))
+}
+
+func TestParseBaseUnits(t *testing.T) {
+	valid := map[string]string{
+		"0":                 "0",
+		"1500000":           "1500000",
+		" 42 ":              "42",
+		"0x0":               "0",
+		"0xde0b6b3a7640000": "1000000000000000000",
+		"0XFF":              "255",
+		"115792089237316195423570985008687907853269984665640564039457584007913129639935": "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+	}
+	for in, want := range valid {
+		raw, err := ParseBaseUnits(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, raw.String(), in)
+	}
+
+	for _, in := range []string{"", "-1", "1.5", "1e18", "0x", "0xzz", "abc", "-0x1"} {
+		_, err := ParseBaseUnits(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestToUnits(t *testing.T) {
+	tests := []struct {
+		raw      string
+		decimals int
+		want     string
+	}{
+		{"0", 18, "0"},
+		{"1", 18, "0.000000000000000001"},
+		{"1000000000000000000", 18, "1"},
+		{"1500000000000000000", 18, "1.5"},
+		{"1500000", 6, "1.5"},
+		{"250250000", 6, "250.25"},
+		{"123456789", 8, "1.23456789"},
+		{"42", 0, "42"},
+		// The classic mistake: a USDC balance read with ETH's decimals
+		{"1000000", 18, "0.000000000001"},
+		{"1000000", 6, "1"},
+		// Out of range decimals are ignored rather than trusted
+		{"42", -3, "42"},
+		{"42", 200, "42"},
+	}
+	for _, tt := range tests {
+		got := ToUnits(bigInt(t, tt.raw), tt.decimals)
+		assert.Equal(t, tt.want, got.String(), "%s with %d decimals", tt.raw, tt.decimals)
+		assert.Equal(t, tt.want, FormatUnits(bigInt(t, tt.raw), tt.decimals))
+	}
+	assert.True(t, ToUnits(nil, 18).IsZero())
+	assert.InDelta(t, 1.5, Float64(bigInt(t, "1500000000000000000"), 18), 1e-12)
+}
+
+func TestFromUnits(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		want     string
+	}{
+		{"0", 18, "0"},
+		{"1", 18, "1000000000000000000"},
+		{"1.5", 6, "1500000"},
+		{"0.000001", 6, "1"},
+		{"250.25", 6, "250250000"},
+		{"1.23456789", 8, "123456789"},
+		{"1.50", 2, "150"},
+		{"42", 0, "42"},
+		{"1e-18", 18, "1"},
+	}
+	for _, tt := range tests {
+		raw, err := ParseUnits(tt.amount, tt.decimals)
+		require.NoError(t, err, tt.amount)
+		assert.Equal(t, tt.want, raw.String(), "%s with %d decimals", tt.amount, tt.decimals)
+
+		// Round trip
+		assert.True(t, ToUnits(raw, tt.decimals).Equal(decimal.MustParse(tt.amount)), tt.amount)
+	}
+
+	invalid := []struct {
+		amount   string
+		decimals int
+	}{
+		{"0.0000001", 6}, // finer than a micro unit
+		{"1.5", 0},
+		{"-1", 18},
+		{"abc", 18},
+		{"1", -1},
+		{"1", 78},
+	}
+	for _, tt := range invalid {
+		_, err := ParseUnits(tt.amount, tt.decimals)
+		assert.Error(t, err, "%s with %d decimals", tt.amount, tt.decimals)
+	}
+}
+
+func TestRescale(t *testing.T) {
+	// USDC bridged from a 6 decimal chain to an 18 decimal one and back
+	scaled, exact := Rescale(bigInt(t, "1500000"), 6, 18)
+	assert.Equal(t, "1500000000000000000", scaled.String())
+	assert.True(t, exact)
+
+	scaled, exact = Rescale(bigInt(t, "1500000000000000000"), 18, 6)
+	assert.Equal(t, "1500000", scaled.String())
+	assert.True(t, exact)
+
+	scaled, exact = Rescale(bigInt(t, "1500000000000000001"), 18, 6)
+	assert.Equal(t, "1500000", scaled.String(), "dust below the smaller unit is truncated")
+	assert.False(t, exact)
+
+	raw := bigInt(t, "42")
+	scaled, exact = Rescale(raw, 8, 8)
+	assert.Equal(t, "42", scaled.String())
+	assert.True(t, exact)
+	scaled.SetInt64(0)
+	assert.Equal(t, "42", raw.String(), "the input isn't modified")
+}
+
+func TestPercent(t *testing.T) {
+	supply := bigInt(t, "420690000000000000000000000000000")
+	assert.InDelta(t, 28.5246, Percent(bigInt(t, "120000000000000000000000000000000"), supply), 0.0001)
+	assert.Equal(t, 100.0, Percent(supply, supply))
+	assert.Equal(t, 0.0, Percent(bigInt(t, "0"), supply))
+	assert.Equal(t, 0.0, Percent(bigInt(t, "1"), bigInt(t, "0")), "nothing is a share of no supply")
+}
+
+func TestUSDValue(t *testing.T) {
+	tests := []struct {
+		raw      string
+		decimals int
+		price    float64
+		want     string
+	}{
+		{"1500000000000000000", 18, 2000, "3000"},
+		{"250250000", 6, 1, "250.25"},
+		{"123456789", 8, 60000, "74074.0734"},
+		{"0", 18, 3000, "0"},
+		{"1000000000000000000", 18, 0, "0"},
+		// Summing these in float64 drifts; the product is exact
+		{"100000000000000000", 18, 0.1, "0.01"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", 18, 1,
+			"115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+	}
+	for _, tt := range tests {
+		value, err := USDValue(bigInt(t, tt.raw), tt.decimals, tt.price)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, value.String(), "%s with %d decimals at %v", tt.raw, tt.decimals, tt.price)
+	}
+
+	for _, price := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), -1} {
+		_, err := USDValue(bigInt(t, "1"), 18, price)
+		assert.Error(t, err, price)
+	}
+	_, err := USDValue(bigInt(t, "1"), -1, 1)
+	assert.Error(t, err)
+}
+
+func TestDisplay(t *testing.T) {
+	tests := map[string]string{
+		"0":           "0",
+		"1234567.891": "1234567.89",
+		"1000":        "1000.00",
+		"999.99995":   "1000.0000",
+		"1.5":         "1.5000",
+		"1":           "1.0000",
+		"0.123456789": "0.123457",
+		"0.000001":    "0.000001",
+		"0.0000009":   "<0.000001",
+		"-0.0000009":  ">-0.000001",
+		"-2.5":        "-2.5000",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, Display(decimal.MustParse(in)), in)
+	}
+}
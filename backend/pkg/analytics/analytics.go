@@ -0,0 +1,209 @@
+// Package analytics sends anonymous usage events to an analytics sink, Segment or
+// ClickHouse, to measure how features are adopted. Events carry a salted hash of the
+// user instead of who they are.
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// SegmentAPIURL is Segment's tracking API
+const SegmentAPIURL = "https://api.segment.io"
+
+// Sink names accepted by NewSink
+const (
+	SinkNone       = ""
+	SinkLog        = "log"
+	SinkSegment    = "segment"
+	SinkClickHouse = "clickhouse"
+)
+
+const httpTimeout = 10 * time.Second
+
+// Event is one usage event
+type Event struct {
+	Name string `json:"event"`
+	// AnonymousID stands for the user, see AnonymousID
+	AnonymousID string                 `json:"anonymousId"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// Sink receives events in batches
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// Config configures the sink events go to
+type Config struct {
+	// Sink is one of the Sink* names; SinkNone sends nothing
+	Sink string
+	// Salt keys the hash events identify users by. Changing it unlinks new events from
+	// old ones.
+	Salt string
+	// SegmentWriteKey is the write key of the Segment source
+	SegmentWriteKey string
+	// ClickHouseURL is the ClickHouse HTTP interface, with credentials if it needs them,
+	// and ClickHouseTable the table events are inserted into
+	ClickHouseURL   string
+	ClickHouseTable string
+}
+
+// NewSink returns the sink cfg names, nil for SinkNone
+func NewSink(cfg Config) (Sink, error) {
+	if cfg.Sink != SinkNone && cfg.Salt == "" {
+		return nil, fmt.Errorf("analytics sink %q needs a salt", cfg.Sink)
+	}
+	switch cfg.Sink {
+	case SinkNone:
+		return nil, nil
+	case SinkLog:
+		return LogSink{}, nil
+	case SinkSegment:
+		if cfg.SegmentWriteKey == "" {
+			return nil, fmt.Errorf("segment analytics sink needs a write key")
+		}
+		return NewSegmentSink(SegmentAPIURL, cfg.SegmentWriteKey), nil
+	case SinkClickHouse:
+		if cfg.ClickHouseURL == "" || cfg.ClickHouseTable == "" {
+			return nil, fmt.Errorf("clickhouse analytics sink needs a URL and table")
+		}
+		return NewClickHouseSink(cfg.ClickHouseURL, cfg.ClickHouseTable), nil
+	default:
+		return nil, fmt.Errorf("unknown analytics sink %q", cfg.Sink)
+	}
+}
+
+// AnonymousID is the HMAC-SHA256 of the user's ID under salt. The same user always gets
+// the same ID, so adoption can be counted per user, but it can't be traced back to them
+// without the salt.
+func AnonymousID(salt, userID string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LogSink writes events to the log, for development
+type LogSink struct{}
+
+func (LogSink) Send(_ context.Context, events []Event) error {
+	for _, event := range events {
+		logger.Info("Analytics event", "event", event.Name, "anonymousId", event.AnonymousID, "properties", event.Properties)
+	}
+	return nil
+}
+
+// SegmentSink sends events to Segment's batch API
+type SegmentSink struct {
+	httpClient *http.Client
+	baseURL    string
+	writeKey   string
+}
+
+func NewSegmentSink(baseURL, writeKey string) *SegmentSink {
+	return &SegmentSink{
+		httpClient: &http.Client{Timeout: httpTimeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		writeKey:   writeKey,
+	}
+}
+
+func (s *SegmentSink) Send(ctx context.Context, events []Event) error {
+	type segmentEvent struct {
+		Type string `json:"type"`
+		Event
+	}
+	batch := make([]segmentEvent, len(events))
+	for i, event := range events {
+		batch[i] = segmentEvent{Type: "track", Event: event}
+	}
+	body, err := json.Marshal(map[string]interface{}{"batch": batch})
+	if err != nil {
+		return fmt.Errorf("failed to encode analytics events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/v1/batch", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(s.writeKey, "")
+	return send(s.httpClient, req, "segment")
+}
+
+// ClickHouseSink inserts events into a ClickHouse table over its HTTP interface. The
+// table needs event, anonymous_id, properties (a JSON string) and timestamp columns.
+type ClickHouseSink struct {
+	httpClient *http.Client
+	url        string
+	table      string
+}
+
+func NewClickHouseSink(url, table string) *ClickHouseSink {
+	return &ClickHouseSink{
+		httpClient: &http.Client{Timeout: httpTimeout},
+		url:        url,
+		table:      table,
+	}
+}
+
+func (s *ClickHouseSink) Send(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, event := range events {
+		properties, err := json.Marshal(event.Properties)
+		if err != nil {
+			return fmt.Errorf("failed to encode analytics event: %w", err)
+		}
+		row := map[string]interface{}{
+			"event":        event.Name,
+			"anonymous_id": event.AnonymousID,
+			"properties":   string(properties),
+			"timestamp":    event.Timestamp.UTC().Format("2006-01-02 15:04:05"),
+		}
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode analytics event: %w", err)
+		}
+	}
+
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return fmt.Errorf("invalid clickhouse URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("query", "INSERT INTO "+s.table+" FORMAT JSONEachRow")
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.String(), &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	return send(s.httpClient, req, "clickhouse")
+}
+
+func send(client *http.Client, req *http.Request, sink string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", sink, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", sink, resp.StatusCode, string(data))
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymousID(t *testing.T) {
+	id := AnonymousID("salt", "user-1")
+	assert.Len(t, id, 64)
+	assert.Equal(t, id, AnonymousID("salt", "user-1"))
+	assert.NotEqual(t, id, AnonymousID("salt", "user-2"))
+	assert.NotEqual(t, id, AnonymousID("other", "user-1"))
+	assert.NotContains(t, id, "user-1")
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, sink)
+
+	_, err = NewSink(Config{Sink: SinkLog})
+	assert.Error(t, err, "a sink needs a salt")
+	_, err = NewSink(Config{Sink: SinkSegment, Salt: "salt"})
+	assert.Error(t, err, "segment needs a write key")
+	_, err = NewSink(Config{Sink: "mixpanel", Salt: "salt"})
+	assert.Error(t, err)
+
+	sink, err = NewSink(Config{Sink: SinkClickHouse, Salt: "salt", ClickHouseURL: "http://localhost:8123", ClickHouseTable: "events"})
+	require.NoError(t, err)
+	assert.IsType(t, &ClickHouseSink{}, sink)
+}
+
+var testEvents = []Event{
+	{Name: "quote_requested", AnonymousID: "abc", Properties: map[string]interface{}{"kind": "swap"}, Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	{Name: "wallet_added", AnonymousID: "def", Timestamp: time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)},
+}
+
+func TestSegmentSink(t *testing.T) {
+	var body struct {
+		Batch []map[string]interface{} `json:"batch"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/batch", r.URL.Path)
+		key, _, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "write-key", key)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewSegmentSink(server.URL, "write-key").Send(context.Background(), testEvents))
+	require.Len(t, body.Batch, 2)
+	assert.Equal(t, "track", body.Batch[0]["type"])
+	assert.Equal(t, "quote_requested", body.Batch[0]["event"])
+	assert.Equal(t, "abc", body.Batch[0]["anonymousId"])
+	assert.Equal(t, "swap", body.Batch[0]["properties"].(map[string]interface{})["kind"])
+}
+
+func TestClickHouseSink(t *testing.T) {
+	var query string
+	var rows []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		data, _ := io.ReadAll(r.Body)
+		rows = strings.Split(strings.TrimSpace(string(data)), "\n")
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewClickHouseSink(server.URL+"/?database=analytics", "events").Send(context.Background(), testEvents))
+	assert.Equal(t, "INSERT INTO events FORMAT JSONEachRow", query)
+	require.Len(t, rows, 2)
+	assert.JSONEq(t, `{"event":"quote_requested","anonymous_id":"abc","properties":"{\"kind\":\"swap\"}","timestamp":"2024-05-01 12:00:00"}`, rows[0])
+}
+
+func TestSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewSegmentSink(server.URL, "bad-key").Send(context.Background(), testEvents)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
\ No newline at end of file
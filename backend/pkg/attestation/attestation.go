@@ -0,0 +1,180 @@
+// Package attestation signs statements of the holdings of a set of wallets at given
+// block heights, in the spirit of a proof of reserves. The server signs the canonical
+// JSON of the statement with an Ed25519 key, so anyone with the public key can check
+// the statement wasn't altered after it was issued.
+package attestation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// Algorithm is the signature scheme of attestations
+	Algorithm = "Ed25519"
+	// Version is the version of the statement format
+	Version = 1
+)
+
+var (
+	// ErrPayloadMismatch is returned when the statement doesn't match the signed payload
+	ErrPayloadMismatch = errors.New("statement does not match the signed payload")
+	// ErrInvalidSignature is returned when the signature doesn't verify under the key
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Statement is what an attestation vouches for: the holdings of the wallets, each read
+// at its chain's block in Blocks. Balances are raw integer amounts in the token's
+// smallest unit; USD values are at the prices of IssuedAt and only informative.
+type Statement struct {
+	Version       int       `json:"version"`
+	KeyID         string    `json:"key_id"`
+	IssuedAt      time.Time `json:"issued_at"`
+	Subject       string    `json:"subject"`
+	Wallets       []Wallet  `json:"wallets"`
+	Blocks        []Block   `json:"blocks"`
+	Holdings      []Holding `json:"holdings"`
+	TotalValueUSD string    `json:"total_value_usd"`
+}
+
+// Wallet is an address attested on a chain
+type Wallet struct {
+	Address string `json:"address"`
+	ChainID int    `json:"chain_id"`
+}
+
+// Block is the block a chain's holdings were read at
+type Block struct {
+	ChainID   int       `json:"chain_id"`
+	Number    int64     `json:"number"`
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Holding is a wallet's balance of a token
+type Holding struct {
+	Address  string `json:"address"`
+	ChainID  int    `json:"chain_id"`
+	Token    string `json:"token"`
+	Symbol   string `json:"symbol"`
+	Decimals int    `json:"decimals"`
+	Balance  string `json:"balance"`
+	ValueUSD string `json:"value_usd,omitempty"`
+}
+
+// Attestation is a signed statement. Payload is the canonical JSON of Statement, the
+// exact bytes the base64 Signature covers.
+type Attestation struct {
+	Statement Statement `json:"statement"`
+	Payload   string    `json:"payload"`
+	Algorithm string    `json:"algorithm"`
+	KeyID     string    `json:"key_id"`
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+}
+
+// Signer issues attestations with the server's key
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner creates a signer from a 32-byte Ed25519 seed
+func NewSigner(seed []byte) (*Signer, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("attestation key must be %d bytes", ed25519.SeedSize)
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}, nil
+}
+
+// KeyID identifies a public key: the first 8 bytes of its SHA-256, in hex
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PublicKey returns the key attestations are verified with
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// KeyID returns the ID of the signer's public key
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign stamps the statement with the signer's key ID and signs its canonical JSON
+func (s *Signer) Sign(statement Statement) (*Attestation, error) {
+	statement.Version = Version
+	statement.KeyID = s.keyID
+	payload, err := Canonicalize(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attestation{
+		Statement: statement,
+		Payload:   string(payload),
+		Algorithm: Algorithm,
+		KeyID:     s.keyID,
+		PublicKey: base64.StdEncoding.EncodeToString(s.PublicKey()),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+	}, nil
+}
+
+// Verify checks the attestation was signed by publicKey and that its statement is the
+// one signed. The public key embedded in the attestation isn't trusted.
+func Verify(attestation *Attestation, publicKey ed25519.PublicKey) error {
+	if attestation.Algorithm != Algorithm {
+		return fmt.Errorf("unsupported algorithm %q", attestation.Algorithm)
+	}
+	signature, err := base64.StdEncoding.DecodeString(attestation.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: not base64", ErrInvalidSignature)
+	}
+	if !ed25519.Verify(publicKey, []byte(attestation.Payload), signature) {
+		return ErrInvalidSignature
+	}
+
+	statement, err := Canonicalize(attestation.Statement)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(statement, []byte(attestation.Payload)) {
+		return ErrPayloadMismatch
+	}
+	return nil
+}
+
+// Canonicalize encodes v as canonical JSON: object keys sorted, no insignificant
+// whitespace and no HTML escaping. Statements hold only strings and integers, so this
+// matches RFC 8785 for them.
+func Canonicalize(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("failed to decode statement: %w", err)
+	}
+
+	// Maps are encoded with sorted keys
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
\ No newline at end of file
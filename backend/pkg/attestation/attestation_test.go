@@ -0,0 +1,77 @@
+package attestation
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement() Statement {
+	return Statement{
+		IssuedAt: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		Subject:  "Fund <A> & co",
+		Wallets:  []Wallet{{Address: "0x8ba1f109551bd432803012645ac136ddd64dba72", ChainID: 1}},
+		Blocks:   []Block{{ChainID: 1, Number: 19000000, Hash: "0xabc", Timestamp: time.Date(2026, 3, 30, 23, 59, 47, 0, time.UTC)}},
+		Holdings: []Holding{{
+			Address:  "0x8ba1f109551bd432803012645ac136ddd64dba72",
+			ChainID:  1,
+			Token:    "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			Symbol:   "USDC",
+			Decimals: 6,
+			Balance:  "2500000000",
+			ValueUSD: "2500.00",
+		}},
+		TotalValueUSD: "2500.00",
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	payload, err := Canonicalize(map[string]interface{}{"b": 1, "a": []int{2, 1}, "c": "<&>"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[2,1],"b":1,"c":"<&>"}`, string(payload))
+
+	// Large integers keep every digit
+	payload, err = Canonicalize(json.RawMessage(`{"n": 12345678901234567890}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"n":12345678901234567890}`, string(payload))
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer, err := NewSigner(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	require.NoError(t, err)
+
+	attestation, err := signer.Sign(testStatement())
+	require.NoError(t, err)
+	assert.Equal(t, Version, attestation.Statement.Version)
+	assert.Equal(t, signer.KeyID(), attestation.Statement.KeyID)
+	assert.Len(t, signer.KeyID(), 16)
+	assert.Contains(t, attestation.Payload, `"blocks":[{"chain_id":1,"hash":"0xabc","number":19000000,"timestamp":"2026-03-30T23:59:47Z"}]`)
+	require.NoError(t, Verify(attestation, signer.PublicKey()))
+
+	// It survives being exported and read back
+	exported, err := json.Marshal(attestation)
+	require.NoError(t, err)
+	var imported Attestation
+	require.NoError(t, json.Unmarshal(exported, &imported))
+	require.NoError(t, Verify(&imported, signer.PublicKey()))
+
+	// Edited holdings no longer match what was signed
+	tampered := imported
+	tampered.Statement.Holdings = []Holding{{Balance: "9999999999"}}
+	assert.ErrorIs(t, Verify(&tampered, signer.PublicKey()), ErrPayloadMismatch)
+
+	// Nor does a re-signed payload under another key
+	other, err := NewSigner(bytes.Repeat([]byte{8}, ed25519.SeedSize))
+	require.NoError(t, err)
+	forged, err := other.Sign(testStatement())
+	require.NoError(t, err)
+	assert.ErrorIs(t, Verify(forged, signer.PublicKey()), ErrInvalidSignature)
+
+	_, err = NewSigner([]byte("short"))
+	assert.Error(t, err)
+}
\ No newline at end of file
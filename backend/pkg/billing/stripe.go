@@ -0,0 +1,303 @@
+// Package billing takes payments through Stripe: it opens checkout sessions for
+// subscriptions and reads the events Stripe posts back about them
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StripeAPIURL is Stripe's API
+const StripeAPIURL = "https://api.stripe.com"
+
+// SignatureTolerance is how old a webhook delivery's signed timestamp may be, so a
+// captured delivery can't be replayed later
+const SignatureTolerance = 5 * time.Minute
+
+const httpTimeout = 30 * time.Second
+
+// Stripe event types handled
+const (
+	EventCheckoutCompleted    = "checkout.session.completed"
+	EventSubscriptionCreated  = "customer.subscription.created"
+	EventSubscriptionUpdated  = "customer.subscription.updated"
+	EventSubscriptionDeleted  = "customer.subscription.deleted"
+	EventInvoiceFinalized     = "invoice.finalized"
+	EventInvoicePaid          = "invoice.paid"
+	EventInvoicePaymentFailed = "invoice.payment_failed"
+	EventInvoiceVoided        = "invoice.voided"
+	EventInvoiceUncollectible = "invoice.marked_uncollectible"
+)
+
+// ErrInvalidSignature is a webhook delivery whose Stripe-Signature doesn't match its
+// body, or whose timestamp is outside SignatureTolerance
+var ErrInvalidSignature = errors.New("invalid stripe signature")
+
+// Config configures the subscription sold through Stripe
+type Config struct {
+	// PriceID is the Stripe price of the subscription
+	PriceID string
+	// SuccessURL and CancelURL are where checkout sends the user back to
+	SuccessURL string
+	CancelURL  string
+	// WebhookSecret is the signing secret of the Stripe webhook endpoint
+	WebhookSecret string
+	// GracePeriod is how long a subscriber keeps what they paid for after a renewal
+	// payment fails
+	GracePeriod time.Duration
+}
+
+// CheckoutParams describe a subscription checkout for one user
+type CheckoutParams struct {
+	PriceID string
+	// ClientReferenceID comes back on the completed session, tying it to the user
+	ClientReferenceID string
+	// CustomerID reuses the user's Stripe customer; without it CustomerEmail prefills a
+	// new one
+	CustomerID    string
+	CustomerEmail string
+	SuccessURL    string
+	CancelURL     string
+}
+
+// CheckoutSession is a hosted checkout page
+type CheckoutSession struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// Event is a webhook delivery. Object is the event's data.object, read with the
+// Subscription, Invoice and Session methods according to Type.
+type Event struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// Session is a checkout session object
+type Session struct {
+	ID                string `json:"id"`
+	ClientReferenceID string `json:"client_reference_id"`
+	Customer          string `json:"customer"`
+	Subscription      string `json:"subscription"`
+	// PaymentStatus is paid, unpaid, or no_payment_required for a trial
+	PaymentStatus string `json:"payment_status"`
+}
+
+// Checkout session payment statuses
+const (
+	PaymentStatusPaid              = "paid"
+	PaymentStatusNoPaymentRequired = "no_payment_required"
+)
+
+// Subscription is a subscription object
+type Subscription struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	Status            string `json:"status"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+}
+
+// Invoice is an invoice object. Amounts are in the currency's smallest unit.
+type Invoice struct {
+	ID               string `json:"id"`
+	Number           string `json:"number"`
+	Customer         string `json:"customer"`
+	Subscription     string `json:"subscription"`
+	Status           string `json:"status"`
+	AmountDue        int64  `json:"amount_due"`
+	AmountPaid       int64  `json:"amount_paid"`
+	Currency         string `json:"currency"`
+	HostedInvoiceURL string `json:"hosted_invoice_url"`
+	InvoicePDF       string `json:"invoice_pdf"`
+	PeriodStart      int64  `json:"period_start"`
+	PeriodEnd        int64  `json:"period_end"`
+	Created          int64  `json:"created"`
+}
+
+// Session reads the event's object as a checkout session
+func (e *Event) Session() (*Session, error) {
+	var session Session
+	if err := json.Unmarshal(e.Data.Object, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode checkout session: %w", err)
+	}
+	return &session, nil
+}
+
+// Subscription reads the event's object as a subscription
+func (e *Event) Subscription() (*Subscription, error) {
+	var subscription Subscription
+	if err := json.Unmarshal(e.Data.Object, &subscription); err != nil {
+		return nil, fmt.Errorf("failed to decode subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+// Invoice reads the event's object as an invoice
+func (e *Event) Invoice() (*Invoice, error) {
+	var invoice Invoice
+	if err := json.Unmarshal(e.Data.Object, &invoice); err != nil {
+		return nil, fmt.Errorf("failed to decode invoice: %w", err)
+	}
+	return &invoice, nil
+}
+
+// ParseEvent checks a webhook delivery's Stripe-Signature header against the endpoint's
+// signing secret and decodes it
+func ParseEvent(payload []byte, header, secret string, now time.Time) (*Event, error) {
+	if err := VerifySignature(payload, header, secret, now); err != nil {
+		return nil, err
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode stripe event: %w", err)
+	}
+	if event.ID == "" || event.Type == "" {
+		return nil, errors.New("stripe event has no id or type")
+	}
+	return &event, nil
+}
+
+// VerifySignature checks a Stripe-Signature header, "t=<unix>,v1=<hex>[,v1=...]", where
+// each v1 is the HMAC-SHA256 of "<t>.<payload>" under the signing secret
+func VerifySignature(payload []byte, header, secret string, now time.Time) error {
+	if secret == "" {
+		return ErrInvalidSignature
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(signedAt, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Sign returns the Stripe-Signature header Stripe would send for payload at t, for
+// tests and local replays of deliveries
+func Sign(payload []byte, secret string, t time.Time) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// StripeClient calls Stripe's API with a secret key
+type StripeClient struct {
+	httpClient *http.Client
+	baseURL    string
+	secretKey  string
+}
+
+func NewStripeClient(baseURL, secretKey string) *StripeClient {
+	return &StripeClient{
+		httpClient: &http.Client{Timeout: httpTimeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		secretKey:  secretKey,
+	}
+}
+
+// CreateCheckoutSession opens a subscription checkout for one unit of the price
+func (c *StripeClient) CreateCheckoutSession(ctx context.Context, params CheckoutParams) (*CheckoutSession, error) {
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("line_items[0][price]", params.PriceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("client_reference_id", params.ClientReferenceID)
+	form.Set("success_url", params.SuccessURL)
+	form.Set("cancel_url", params.CancelURL)
+	if params.CustomerID != "" {
+		form.Set("customer", params.CustomerID)
+	} else if params.CustomerEmail != "" {
+		form.Set("customer_email", params.CustomerEmail)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/checkout/sessions", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var session CheckoutSession
+	if err := c.do(req, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetSubscription reads a subscription as it is now
+func (c *StripeClient) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/subscriptions/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var subscription Subscription
+	if err := c.do(req, &subscription); err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// do sends an authenticated request and decodes the object returned into out
+func (c *StripeClient) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("stripe returned status %d: %s", resp.StatusCode, string(data))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode stripe response: %w", err)
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,98 @@
+package billing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEvent(t *testing.T) {
+	secret := "whsec_test"
+	now := time.Unix(1700000000, 0)
+	payload := []byte(`{"id":"evt_1","type":"invoice.paid","created":1700000000,"data":{"object":{"id":"in_1","customer":"cus_1","amount_paid":900,"currency":"usd"}}}`)
+
+	event, err := ParseEvent(payload, Sign(payload, secret, now), secret, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, EventInvoicePaid, event.Type)
+	invoice, err := event.Invoice()
+	require.NoError(t, err)
+	assert.Equal(t, "cus_1", invoice.Customer)
+	assert.Equal(t, int64(900), invoice.AmountPaid)
+
+	tests := []struct {
+		name   string
+		header string
+		at     time.Time
+		secret string
+	}{
+		{"wrong secret", Sign(payload, "whsec_other", now), now, secret},
+		{"replayed", Sign(payload, secret, now), now.Add(SignatureTolerance + time.Second), secret},
+		{"no signature", "t=1700000000", now, secret},
+		{"no secret configured", Sign(payload, "", now), now, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseEvent(payload, tc.header, tc.secret, tc.at)
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+
+	// Any of several v1 signatures may match, as while a secret is being rolled
+	header := Sign(payload, secret, now) + ",v1=00ff"
+	_, err = ParseEvent(payload, header, secret, now)
+	assert.NoError(t, err)
+}
+
+func TestCreateCheckoutSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/checkout/sessions", r.URL.Path)
+		assert.Equal(t, "Bearer sk_test", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "subscription", r.PostForm.Get("mode"))
+		assert.Equal(t, "price_pro", r.PostForm.Get("line_items[0][price]"))
+		assert.Equal(t, "user-1", r.PostForm.Get("client_reference_id"))
+		assert.Equal(t, "cus_1", r.PostForm.Get("customer"))
+		assert.Empty(t, r.PostForm.Get("customer_email"), "an existing customer isn't prefilled")
+		w.Write([]byte(`{"id":"cs_1","url":"https://checkout.stripe.com/c/cs_1"}`))
+	}))
+	defer server.Close()
+
+	client := NewStripeClient(server.URL, "sk_test")
+	session, err := client.CreateCheckoutSession(context.Background(), CheckoutParams{
+		PriceID:           "price_pro",
+		ClientReferenceID: "user-1",
+		CustomerID:        "cus_1",
+		CustomerEmail:     "user@example.org",
+		SuccessURL:        "https://app.example.org/billing?ok=1",
+		CancelURL:         "https://app.example.org/billing",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cs_1", session.ID)
+	assert.Equal(t, "https://checkout.stripe.com/c/cs_1", session.URL)
+}
+func TestGetSubscription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "Bearer sk_test", r.Header.Get("Authorization"))
+		if r.URL.Path != "/v1/subscriptions/sub_1" {
+			http.Error(w, `{"error":{"type":"invalid_request_error"}}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"sub_1","customer":"cus_1","status":"trialing","current_period_end":1780315200}`))
+	}))
+	defer server.Close()
+
+	client := NewStripeClient(server.URL, "sk_test")
+	subscription, err := client.GetSubscription(context.Background(), "sub_1")
+	require.NoError(t, err)
+	assert.Equal(t, &Subscription{ID: "sub_1", Customer: "cus_1", Status: "trialing", CurrentPeriodEnd: 1780315200}, subscription)
+
+	_, err = client.GetSubscription(context.Background(), "sub_missing")
+	assert.Error(t, err)
+}
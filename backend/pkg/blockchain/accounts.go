@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// Safes answer getThreshold() with their signer threshold
+	selGetThreshold = methodID("getThreshold()")
+	// ERC-4337 accounts answer entryPoint() with the entry point they trust
+	selEntryPoint = methodID("entryPoint()")
+	// EIP-1271 contracts answer isValidSignature() with its own selector for signatures
+	// they accept
+	selIsValidSignature = methodID("isValidSignature(bytes32,bytes)")
+)
+
+// eip7702Prefix starts the code of an EOA delegating to a contract under EIP-7702
+var eip7702Prefix = []byte{0xef, 0x01, 0x00}
+
+// DetectAccountType returns what address holds on the chain as one of the
+// models.WalletAccount* values: an EOA, a Safe, an ERC-4337 account or another contract
+func (s *BlockchainService) DetectAccountType(ctx context.Context, chainID int, address string) (string, error) {
+	return s.alchemyClient.detectAccountType(ctx, chainID, address)
+}
+
+// detectAccountType reads the code at address and probes the Safe and ERC-4337 getters
+// in one batch
+func (c *AlchemyClient) detectAccountType(ctx context.Context, chainID int, address string) (string, error) {
+	probe := func(selector []byte) rpcRequest {
+		return rpcRequest{Method: "eth_call", Params: []interface{}{
+			map[string]string{"to": address, "data": "0x" + hex.EncodeToString(selector)},
+			"latest",
+		}}
+	}
+	responses, err := c.batchCall(ctx, chainID, []rpcRequest{
+		{Method: "eth_getCode", Params: []interface{}{address, "latest"}},
+		probe(selGetThreshold),
+		probe(selEntryPoint),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	code, err := decodeCallResult(responses[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to get code: %w", err)
+	}
+	return classifyAccount(code, responses[1], responses[2]), nil
+}
+
+// classifyAccount tells the account type from the code at an address and its answers to
+// getThreshold() and entryPoint(). Calls to an address without code succeed with no data,
+// so the probes only count for contracts.
+func classifyAccount(code []byte, threshold, entryPoint rpcResponse) string {
+	if len(code) == 0 || bytes.HasPrefix(code, eip7702Prefix) {
+		return models.WalletAccountEOA
+	}
+	if data, err := decodeCallResult(threshold); err == nil && len(data) >= 32 && decodeUint(data, 0).Sign() > 0 {
+		return models.WalletAccountSafe
+	}
+	if data, err := decodeCallResult(entryPoint); err == nil && len(data) >= 32 && decodeAddress(data, 0) != (common.Address{}) {
+		return models.WalletAccountERC4337
+	}
+	return models.WalletAccountContract
+}
+
+// IsValidSignature asks the contract at account whether it accepts signature for hash,
+// following EIP-1271. Contracts that reject the signature, revert, or aren't contracts at
+// all give false; only a failed call gives an error.
+func (s *BlockchainService) IsValidSignature(ctx context.Context, chainID int, account string, hash common.Hash, signature []byte) (bool, error) {
+	data := append(append([]byte{}, selIsValidSignature...), hash.Bytes()...)
+	data = append(data, encodeUint(big.NewInt(64))...)
+	data = append(data, encodeBytes(signature)...)
+
+	// A batch of one keeps the node's rejections, which come back as call errors, apart
+	// from failures to reach it
+	responses, err := s.alchemyClient.batchCall(ctx, chainID, []rpcRequest{{Method: "eth_call", Params: []interface{}{
+		map[string]string{"to": account, "data": "0x" + hex.EncodeToString(data)},
+		"latest",
+	}}})
+	if err != nil {
+		return false, err
+	}
+	result, err := decodeCallResult(responses[0])
+	if err != nil {
+		return false, nil
+	}
+	return isEIP1271MagicValue(result), nil
+}
+
+// isEIP1271MagicValue reports whether an isValidSignature() result is the function's
+// selector, left-aligned in a bytes4 word, which is how contracts accept a signature
+func isEIP1271MagicValue(result []byte) bool {
+	return len(result) >= 4 && bytes.Equal(result[:4], selIsValidSignature)
+}
@@ -0,0 +1,102 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callResult builds the response to an eth_call returning data
+func callResult(data []byte) rpcResponse {
+	result, _ := json.Marshal("0x" + hex.EncodeToString(data))
+	return rpcResponse{Result: result}
+}
+
+func TestClassifyAccount(t *testing.T) {
+	reverted := rpcResponse{Error: &rpcError{Code: -32000, Message: "execution reverted"}}
+	empty := callResult(nil)
+	contract := []byte{0x60, 0x80, 0x60, 0x40}
+
+	assert.Equal(t, models.WalletAccountEOA, classifyAccount(nil, empty, empty))
+	delegated := append(append([]byte{}, eip7702Prefix...), make([]byte, 20)...)
+	assert.Equal(t, models.WalletAccountEOA, classifyAccount(delegated, reverted, reverted), "EIP-7702 delegation keeps the key")
+
+	threshold := callResult(encodeUint(big.NewInt(2)))
+	assert.Equal(t, models.WalletAccountSafe, classifyAccount(contract, threshold, reverted))
+
+	entryPoint := callResult(encodeAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032"))
+	assert.Equal(t, models.WalletAccountERC4337, classifyAccount(contract, reverted, entryPoint))
+
+	assert.Equal(t, models.WalletAccountContract, classifyAccount(contract, reverted, reverted))
+	assert.Equal(t, models.WalletAccountContract, classifyAccount(contract, callResult(encodeUint(big.NewInt(0))), callResult(make([]byte, 32))),
+		"zero answers don't identify the account")
+}
+
+func TestIsValidSignature(t *testing.T) {
+	const (
+		safe     = "0x1111111111111111111111111111111111111111"
+		rejecter = "0x2222222222222222222222222222222222222222"
+		eoa      = "0x3333333333333333333333333333333333333333"
+	)
+	hash := common.HexToHash("0xabcdef")
+	signature := []byte{1, 2, 3}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var calls []struct {
+			ID     int               `json:"id"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&calls))
+		require.Len(t, calls, 1)
+		var tx map[string]string
+		require.NoError(t, json.Unmarshal(calls[0].Params[0], &tx))
+
+		data, err := hex.DecodeString(strings.TrimPrefix(tx["data"], "0x"))
+		require.NoError(t, err)
+		assert.Equal(t, selIsValidSignature, data[:4])
+		assert.Equal(t, hash.Bytes(), data[4:36])
+		assert.Equal(t, encodeBytes(signature), data[68:])
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": calls[0].ID}
+		switch tx["to"] {
+		case safe:
+			magic := make([]byte, 32)
+			copy(magic, selIsValidSignature)
+			response["result"] = "0x" + hex.EncodeToString(magic)
+		case rejecter:
+			response["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+		default:
+			response["result"] = "0x"
+		}
+		json.NewEncoder(w).Encode([]interface{}{response})
+	}))
+	defer server.Close()
+
+	service := &BlockchainService{alchemyClient: &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}}
+	ctx := context.Background()
+
+	valid, err := service.IsValidSignature(ctx, 1, safe, hash, signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = service.IsValidSignature(ctx, 1, rejecter, hash, signature)
+	require.NoError(t, err)
+	assert.False(t, valid, "reverting means rejecting")
+
+	valid, err = service.IsValidSignature(ctx, 1, eoa, hash, signature)
+	require.NoError(t, err)
+	assert.False(t, valid, "addresses without code accept nothing")
+
+	_, err = service.IsValidSignature(ctx, 999999, safe, hash, signature)
+	assert.Error(t, err, "unsupported chain")
+}
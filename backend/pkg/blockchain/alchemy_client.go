@@ -3,14 +3,20 @@ package blockchain
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/hexutil"
 	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/google/uuid"
 )
@@ -23,8 +29,25 @@ const (
 	PolygonAmoyURL = "https://rpc-amoy.polygon.technology" // Public RPC for testnet
 )
 
+// ErrRateLimited is returned, wrapped, when Alchemy throttles a request. Callers working
+// through many wallets back off on it rather than counting the wallet as failed.
+var ErrRateLimited = errors.New("rate limited")
+
+// alchemyError describes a failed Alchemy call, marking throttling as ErrRateLimited and
+// as an exhausted quota, so jobs leave Alchemy alone until it resets
+func alchemyError(status, code int, message string) error {
+	if status == http.StatusTooManyRequests || code == http.StatusTooManyRequests {
+		return &external.QuotaError{
+			Provider: external.ProviderAlchemy,
+			Err:      fmt.Errorf("alchemy API error: %s: %w", message, ErrRateLimited),
+		}
+	}
+	return fmt.Errorf("alchemy API error: %s", message)
+}
+
 type AlchemyClient struct {
 	httpClient *http.Client
+	mu         sync.RWMutex
 	apiKey     string
 	baseURLs   map[int]string
 }
@@ -32,17 +55,45 @@ type AlchemyClient struct {
 func NewAlchemyClient(apiKey string) *AlchemyClient {
 	return &AlchemyClient{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		apiKey: apiKey,
-		baseURLs: map[int]string{
-			1:     fmt.Sprintf("%s/%s", AlchemyMainnetURL, apiKey),
-			137:   fmt.Sprintf("%s/%s", AlchemyPolygonURL, apiKey),
-			42161: fmt.Sprintf("%s/%s", AlchemyArbitrumURL, apiKey),
-			10:    fmt.Sprintf("%s/%s", AlchemyOptimismURL, apiKey),
-			80002: PolygonAmoyURL, // Polygon Amoy testnet uses public RPC, no API key needed
+			Timeout:   30 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
 		},
+		apiKey:   apiKey,
+		baseURLs: alchemyBaseURLs(apiKey),
+	}
+}
+
+func alchemyBaseURLs(apiKey string) map[int]string {
+	return map[int]string{
+		1:     fmt.Sprintf("%s/%s", AlchemyMainnetURL, apiKey),
+		137:   fmt.Sprintf("%s/%s", AlchemyPolygonURL, apiKey),
+		42161: fmt.Sprintf("%s/%s", AlchemyArbitrumURL, apiKey),
+		10:    fmt.Sprintf("%s/%s", AlchemyOptimismURL, apiKey),
+		80002: PolygonAmoyURL, // Polygon Amoy testnet uses public RPC, no API key needed
+	}
+}
+
+// SetAPIKey rotates the Alchemy key; in-flight requests finish on the old URL
+func (c *AlchemyClient) SetAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = apiKey
+	c.baseURLs = alchemyBaseURLs(apiKey)
+}
+
+// baseURL returns the RPC endpoint for a chain with the current key embedded, or the
+// registered endpoint of a custom chain
+func (c *AlchemyClient) baseURL(chainID int) (string, bool) {
+	c.mu.RLock()
+	url, ok := c.baseURLs[chainID]
+	c.mu.RUnlock()
+	if ok {
+		return url, true
+	}
+	if chain, ok := LookupCustomChain(chainID); ok {
+		return chain.RPCURL, true
 	}
+	return "", false
 }
 
 type TokenBalance struct {
@@ -51,6 +102,12 @@ type TokenBalance struct {
 	Error            string `json:"error,omitempty"`
 }
 
+// held reports whether the balance was read and isn't zero. Zero balances may come back
+// as "0x0" or as a padded 32-byte word.
+func (b TokenBalance) held() bool {
+	return b.Error == "" && strings.TrimLeft(strings.TrimPrefix(b.TokenBalance, "0x"), "0") != ""
+}
+
 type TokenMetadata struct {
 	Decimals int    `json:"decimals"`
 	Logo     string `json:"logo"`
@@ -63,26 +120,34 @@ type AlchemyTokenBalanceResponse struct {
 	TokenBalances []TokenBalance `json:"tokenBalances"`
 }
 
-type AlchemyTokenMetadataResponse struct {
-	Data []TokenMetadata `json:"data"`
-}
-
 type AlchemyTransactionResponse struct {
 	Result struct {
 		Transfers []TransferData `json:"transfers"`
+		PageKey   string         `json:"pageKey"`
 	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
 }
 
 type TransferData struct {
-	BlockNum    string        `json:"blockNum"`
-	Hash        string        `json:"hash"`
-	From        string        `json:"from"`
-	To          string        `json:"to"`
-	Value       float64       `json:"value"`
-	Asset       string        `json:"asset"`
-	Category    string        `json:"category"`
-	RawContract RawContract   `json:"rawContract"`
-	Metadata    TransferMeta  `json:"metadata"`
+	BlockNum        string            `json:"blockNum"`
+	Hash            string            `json:"hash"`
+	From            string            `json:"from"`
+	To              string            `json:"to"`
+	Value           float64           `json:"value"`
+	Asset           string            `json:"asset"`
+	Category        string            `json:"category"`
+	ERC721TokenID   string            `json:"erc721TokenId"`
+	ERC1155Metadata []ERC1155Metadata `json:"erc1155Metadata"`
+	RawContract     RawContract       `json:"rawContract"`
+	Metadata        TransferMeta      `json:"metadata"`
+}
+
+type ERC1155Metadata struct {
+	TokenID string `json:"tokenId"`
+	Value   string `json:"value"`
 }
 
 type RawContract struct {
@@ -95,70 +160,59 @@ type TransferMeta struct {
 	BlockTimestamp string `json:"blockTimestamp"`
 }
 
-// GetTokenBalances fetches ERC20 token balances for an address
+// GetTokenBalances fetches the token balances of an address, with its native balance
+// listed first as the chain's native token. Custom chains have no token indexer, so
+// only their native balance is read. A native balance that can't be read is left out,
+// unless the provider is throttling, when the result would look like the wallet holds
+// none.
 func (c *AlchemyClient) GetTokenBalances(ctx context.Context, address string, chainID int) ([]*models.Balance, error) {
-	baseURL, exists := c.baseURLs[chainID]
-	if !exists {
+	if _, exists := c.baseURL(chainID); !exists {
 		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
 	}
 
 	// Special handling for Polygon Amoy (public RPC, no Alchemy methods)
 	if chainID == 80002 {
-		return c.getTokenBalancesPublicRPC(ctx, address, chainID, baseURL)
-	}
-
-	// Get token balances using Alchemy-specific method
-	reqBody := map[string]interface{}{
-		"id":      1,
-		"jsonrpc": "2.0",
-		"method":  "alchemy_getTokenBalances",
-		"params":  []interface{}{address},
+		return c.getTokenBalancesPublicRPC(ctx, address, chainID)
 	}
 
-	reqBytes, err := json.Marshal(reqBody)
+	// Token and native balances are read in one batch
+	holdings, err := c.getWalletHoldings(ctx, address, chainID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var balanceResp struct {
-		Result AlchemyTokenBalanceResponse `json:"result"`
-		Error  *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
+	if errors.Is(holdings.nativeErr, ErrRateLimited) {
+		return nil, fmt.Errorf("failed to get native balance: %w", holdings.nativeErr)
+	} else if holdings.nativeErr != nil {
+		logger.Error("Failed to get native balance", "chainId", chainID, "error", holdings.nativeErr)
 	}
+	return holdings.balances(chainID), nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&balanceResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if balanceResp.Error != nil {
-		return nil, fmt.Errorf("alchemy API error: %s", balanceResp.Error.Message)
+// nativeBalance lists a native balance as a balance of the chain's native token
+func nativeBalance(chainID int, amount *big.Int) *models.Balance {
+	token := NativeToken(chainID)
+	return &models.Balance{
+		ID:       uuid.New(),
+		WalletID: uuid.New(), // This should be set by the service
+		TokenID:  token.ID,
+		Token:    token,
+		Balance:  amount.String(),
 	}
+}
 
+// toBalances turns the held tokens of an alchemy_getTokenBalances result into balances,
+// leaving out tokens without metadata
+func (c *AlchemyClient) toBalances(ctx context.Context, chainID int, tokenBalances []TokenBalance) []*models.Balance {
 	// Get metadata for tokens with non-zero balances
 	var tokenAddresses []string
-	for _, balance := range balanceResp.Result.TokenBalances {
-		if balance.TokenBalance != "0x0" && balance.Error == "" {
+	for _, balance := range tokenBalances {
+		if balance.held() {
 			tokenAddresses = append(tokenAddresses, balance.ContractAddress)
 		}
 	}
 
 	if len(tokenAddresses) == 0 {
-		return []*models.Balance{}, nil
+		return []*models.Balance{}
 	}
 
 	// Get token metadata
@@ -171,8 +225,8 @@ func (c *AlchemyClient) GetTokenBalances(ctx context.Context, address string, ch
 
 	// Convert to models.Balance
 	var balances []*models.Balance
-	for _, tokenBalance := range balanceResp.Result.TokenBalances {
-		if tokenBalance.TokenBalance == "0x0" || tokenBalance.Error != "" {
+	for _, tokenBalance := range tokenBalances {
+		if !tokenBalance.held() {
 			continue
 		}
 
@@ -183,8 +237,11 @@ func (c *AlchemyClient) GetTokenBalances(ctx context.Context, address string, ch
 		}
 
 		// Convert hex balance to decimal
-		balanceInt := new(big.Int)
-		balanceInt.SetString(tokenBalance.TokenBalance[2:], 16) // Remove 0x prefix
+		balanceInt, err := hexutil.DecodeBig(tokenBalance.TokenBalance)
+		if err != nil {
+			logger.Warn("Skipping token with malformed balance", "error", err, "token", tokenBalance.ContractAddress, "chainId", chainID)
+			continue
+		}
 
 		// Create token
 		token := &models.Token{
@@ -208,21 +265,21 @@ func (c *AlchemyClient) GetTokenBalances(ctx context.Context, address string, ch
 		balances = append(balances, balance)
 	}
 
-	return balances, nil
+	return balances
 }
 
-// getTokenMetadata fetches metadata for multiple tokens
-func (c *AlchemyClient) getTokenMetadata(ctx context.Context, addresses []string, chainID int) (map[string]TokenMetadata, error) {
-	baseURL, exists := c.baseURLs[chainID]
+// GetETHBalance fetches native ETH balance for an address
+func (c *AlchemyClient) GetETHBalance(ctx context.Context, address string, chainID int) (*big.Int, error) {
+	baseURL, exists := c.baseURL(chainID)
 	if !exists {
 		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
 	}
 
 	reqBody := map[string]interface{}{
-		"id":      1,
+		"id":      1,  
 		"jsonrpc": "2.0",
-		"method":  "alchemy_getTokenMetadata",
-		"params":  []interface{}{addresses},
+		"method":  "eth_getBalance",
+		"params":  []interface{}{address, "latest"},
 	}
 
 	reqBytes, err := json.Marshal(reqBody)
@@ -243,144 +300,135 @@ func (c *AlchemyClient) getTokenMetadata(ctx context.Context, addresses []string
 	}
 	defer resp.Body.Close()
 
-	var metadataResp struct {
-		Result AlchemyTokenMetadataResponse `json:"result"`
+	var balanceResp struct {
+		Result string `json:"result"`
 		Error  *struct {
 			Code    int    `json:"code"`
 			Message string `json:"message"`
 		} `json:"error"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&metadataResp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&balanceResp); err != nil {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, alchemyError(resp.StatusCode, 0, resp.Status)
+		}
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if metadataResp.Error != nil {
-		return nil, fmt.Errorf("alchemy API error: %s", metadataResp.Error.Message)
+	if balanceResp.Error != nil {
+		return nil, alchemyError(resp.StatusCode, balanceResp.Error.Code, balanceResp.Error.Message)
 	}
 
-	// Create address -> metadata mapping
-	result := make(map[string]TokenMetadata)
-	for i, meta := range metadataResp.Result.Data {
-		if i < len(addresses) {
-			result[addresses[i]] = meta
-		}
+	balance, err := hexutil.DecodeBig(balanceResp.Result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance %q: %w", balanceResp.Result, err)
 	}
 
-	return result, nil
+	return balance, nil
 }
 
-// GetETHBalance fetches native ETH balance for an address
-func (c *AlchemyClient) GetETHBalance(ctx context.Context, address string, chainID int) (*big.Int, error) {
-	baseURL, exists := c.baseURLs[chainID]
-	if !exists {
-		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
-	}
-
-	reqBody := map[string]interface{}{
-		"id":      1,  
-		"jsonrpc": "2.0",
-		"method":  "eth_getBalance",
-		"params":  []interface{}{address, "latest"},
-	}
+// walletHoldings are an address's token balances and native balance on a chain
+type walletHoldings struct {
+	tokens []*models.Balance
+	native *big.Int
+	// nativeErr is why the native balance couldn't be read, which doesn't fail the tokens
+	nativeErr error
+}
 
-	reqBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// balances lists the holdings, the native balance first when there is one
+func (h *walletHoldings) balances(chainID int) []*models.Balance {
+	if h.native == nil || h.native.Sign() <= 0 {
+		return h.tokens
 	}
+	return append([]*models.Balance{nativeBalance(chainID, h.native)}, h.tokens...)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+// getWalletHoldings reads an address's token balances and native balance in one batch
+// rather than a round trip each. Custom chains have no token indexer, so only their
+// native balance is read. Polygon Amoy isn't supported; its tokens come from
+// GetTokenBalances along with the native balance.
+func (c *AlchemyClient) getWalletHoldings(ctx context.Context, address string, chainID int) (*walletHoldings, error) {
+	_, custom := LookupCustomChain(chainID)
+	requests := []rpcRequest{{Method: "eth_getBalance", Params: []interface{}{address, "latest"}}}
+	if !custom {
+		requests = append(requests, rpcRequest{Method: "alchemy_getTokenBalances", Params: []interface{}{address}})
+	}
+	responses, err := c.batchCall(ctx, chainID, requests)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	holdings := &walletHoldings{tokens: []*models.Balance{}}
+	if !custom {
+		if responses[1].Error != nil {
+			return nil, alchemyError(http.StatusOK, responses[1].Error.Code, responses[1].Error.Message)
+		}
+		var result AlchemyTokenBalanceResponse
+		if err := json.Unmarshal(responses[1].Result, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode token balances: %w", err)
+		}
+		holdings.tokens = c.toBalances(ctx, chainID, result.TokenBalances)
 	}
-	defer resp.Body.Close()
 
-	var balanceResp struct {
-		Result string `json:"result"`
-		Error  *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
+	if responses[0].Error != nil {
+		holdings.nativeErr = alchemyError(http.StatusOK, responses[0].Error.Code, responses[0].Error.Message)
+		return holdings, nil
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&balanceResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	var native string
+	if err := json.Unmarshal(responses[0].Result, &native); err != nil {
+		holdings.nativeErr = fmt.Errorf("failed to decode balance: %w", err)
+		return holdings, nil
 	}
-
-	if balanceResp.Error != nil {
-		return nil, fmt.Errorf("alchemy API error: %s", balanceResp.Error.Message)
+	if holdings.native, err = hexutil.DecodeBig(native); err != nil {
+		holdings.nativeErr = fmt.Errorf("invalid balance %q: %w", native, err)
 	}
-
-	// Convert hex to big.Int
-	balance := new(big.Int)
-	balance.SetString(balanceResp.Result[2:], 16) // Remove 0x prefix
-
-	return balance, nil
+	return holdings, nil
 }
 
-// GetTransactions fetches recent transactions for an address
+// GetTransactions fetches every transaction sent or received by an address
 func (c *AlchemyClient) GetTransactions(ctx context.Context, address string, chainID int) ([]*models.Transaction, error) {
-	baseURL, exists := c.baseURLs[chainID]
+	return c.GetTransactionsInRange(ctx, address, chainID, BlockRange{})
+}
+
+// GetTransactionsInRange fetches the transactions sent or received by an address within
+// a block range, oldest first. Transfers are followed across pages, and a transaction
+// appearing in both directions, such as a swap or a self-send, is returned once.
+func (c *AlchemyClient) GetTransactionsInRange(ctx context.Context, address string, chainID int, blocks BlockRange) ([]*models.Transaction, error) {
+	baseURL, exists := c.baseURL(chainID)
 	if !exists {
 		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
 	}
 
 	// Special handling for Polygon Amoy (public RPC, no Alchemy methods)
-	if chainID == 80002 {
+	if _, custom := LookupCustomChain(chainID); custom || chainID == 80002 {
 		return c.getTransactionsPublicRPC(ctx, address, chainID, baseURL)
 	}
 
-	reqBody := map[string]interface{}{
-		"id":      1,
-		"jsonrpc": "2.0",
-		"method":  "alchemy_getAssetTransfers",
-		"params": []map[string]interface{}{
-			{
-				"fromBlock":         "0x0",
-				"toBlock":           "latest",
-				"fromAddress":       address,
-				"category":          []string{"external", "internal", "erc20", "erc721", "erc1155"},
-				"withMetadata":      true,
-				"excludeZeroValue":  true,
-				"maxCount":          "0x64", // 100 transactions
-			},
-		},
+	query := func(direction string) map[string]interface{} {
+		filter := blocks.filter()
+		filter[direction] = address
+		filter["category"] = []string{"external", "internal", "erc20"} // NFTs are parsed separately by getNFTTransfers
+		filter["excludeZeroValue"] = true
+		return filter
 	}
 
-	reqBytes, err := json.Marshal(reqBody)
+	outgoing, err := c.getAssetTransfers(ctx, baseURL, query("fromAddress"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to get outgoing transfers: %w", err)
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+	incoming, err := c.getAssetTransfers(ctx, baseURL, query("toAddress"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var txResp AlchemyTransactionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&txResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to get incoming transfers: %w", err)
 	}
 
 	// Convert to models.Transaction
 	var transactions []*models.Transaction
-	for _, transfer := range txResp.Result.Transfers {
-		blockNum, _ := strconv.ParseInt(transfer.BlockNum[2:], 16, 64)
+	for _, transfer := range mergeTransfers(outgoing, incoming) {
+		blockNum, err := hexutil.DecodeInt64(transfer.BlockNum)
+		if err != nil {
+			logger.Warn("Skipping transfer with malformed block number", "error", err, "hash", transfer.Hash, "chainId", chainID)
+			continue
+		}
 		
 		// Parse timestamp
 		timestamp, err := time.Parse(time.RFC3339, transfer.Metadata.BlockTimestamp)
@@ -422,171 +470,55 @@ var polygonAmoyTokens = map[string]struct {
 	"0x0Fd9e8d3aF1aaee056EB9e802c3A762a667b1904": {Symbol: "LINK", Name: "Chainlink", Decimals: 18},
 }
 
-// getTokenBalancesPublicRPC handles token balance fetching for public RPC endpoints
-func (c *AlchemyClient) getTokenBalancesPublicRPC(ctx context.Context, address string, chainID int, baseURL string) ([]*models.Balance, error) {
-	var balances []*models.Balance
-	
-	// 1. Get native token balance using standard eth_getBalance
-	reqBody := map[string]interface{}{
-		"id":      1,
-		"jsonrpc": "2.0",
-		"method":  "eth_getBalance",
-		"params":  []interface{}{address, "latest"},
-	}
-
-	reqBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// getTokenBalancesPublicRPC reads the native balance and the balances of known tokens
+// from a public RPC endpoint, in a single Multicall3 batch
+func (c *AlchemyClient) getTokenBalancesPublicRPC(ctx context.Context, address string, chainID int) ([]*models.Balance, error) {
+	var tokens []string
+	if chainID == 80002 {
+		for tokenAddr := range polygonAmoyTokens {
+			tokens = append(tokens, tokenAddr)
+		}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	amounts, err := c.erc20Balances(ctx, chainID, address, tokens)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var balanceResp struct {
-		Result string `json:"result"`
-		Error  *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
+		return nil, fmt.Errorf("failed to read balances: %w", err)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&balanceResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if balanceResp.Error != nil {
-		return nil, fmt.Errorf("RPC error: %s", balanceResp.Error.Message)
+	var balances []*models.Balance
+	if native, ok := amounts[NativeTokenAddress]; ok && native.Sign() > 0 {
+		balances = append(balances, nativeBalance(chainID, native))
 	}
 
-	// Add native balance if non-zero
-	if balanceResp.Result != "0x0" && balanceResp.Result != "" {
-		// Parse hex balance to big.Int
-		balanceInt := new(big.Int)
-		balanceInt.SetString(balanceResp.Result[2:], 16) // Remove 0x prefix
-		
-		balance := &models.Balance{
+	for _, tokenAddr := range tokens {
+		amount, ok := amounts[tokenAddr]
+		if !ok {
+			logger.Error("Failed to get ERC20 balance", "token", tokenAddr, "chainId", chainID)
+			continue
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+		tokenInfo := polygonAmoyTokens[tokenAddr]
+		balances = append(balances, &models.Balance{
 			ID:       uuid.New(),
 			WalletID: uuid.New(),
 			TokenID:  uuid.New(),
-			Balance:  balanceInt.String(), // Store as decimal string
+			Balance:  amount.String(),
 			Token: &models.Token{
 				ID:       uuid.New(),
-				Address:  "0x0000000000000000000000000000000000000000", // Native token
+				Address:  tokenAddr,
 				ChainID:  chainID,
-				Symbol:   "MATIC",
-				Name:     "Polygon",
-				Decimals: 18,
+				Symbol:   tokenInfo.Symbol,
+				Name:     tokenInfo.Name,
+				Decimals: tokenInfo.Decimals,
 			},
-		}
-		balances = append(balances, balance)
-	}
-
-	// 2. Get ERC20 token balances for known tokens on Polygon Amoy
-	if chainID == 80002 {
-		for tokenAddr, tokenInfo := range polygonAmoyTokens {
-			balance, err := c.getERC20Balance(ctx, address, tokenAddr, baseURL)
-			if err != nil {
-				logger.Error("Failed to get ERC20 balance", "token", tokenAddr, "error", err)
-				continue
-			}
-			
-			if balance != "0" {
-				tokenBalance := &models.Balance{
-					ID:       uuid.New(),
-					WalletID: uuid.New(),
-					TokenID:  uuid.New(),
-					Balance:  balance,
-					Token: &models.Token{
-						ID:       uuid.New(),
-						Address:  tokenAddr,
-						ChainID:  chainID,
-						Symbol:   tokenInfo.Symbol,
-						Name:     tokenInfo.Name,
-						Decimals: tokenInfo.Decimals,
-					},
-				}
-				balances = append(balances, tokenBalance)
-			}
-		}
+		})
 	}
 
 	return balances, nil
 }
 
-// getERC20Balance fetches balance for a specific ERC20 token
-func (c *AlchemyClient) getERC20Balance(ctx context.Context, walletAddress, tokenAddress, baseURL string) (string, error) {
-	// ERC20 balanceOf method signature: 0x70a08231
-	// Pad address to 32 bytes
-	paddedAddress := fmt.Sprintf("0x70a08231%064s", walletAddress[2:])
-	
-	reqBody := map[string]interface{}{
-		"id":      1,
-		"jsonrpc": "2.0",
-		"method":  "eth_call",
-		"params": []interface{}{
-			map[string]string{
-				"to":   tokenAddress,
-				"data": paddedAddress,
-			},
-			"latest",
-		},
-	}
-
-	reqBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return "0", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
-	if err != nil {
-		return "0", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "0", fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var callResp struct {
-		Result string `json:"result"`
-		Error  *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&callResp); err != nil {
-		return "0", fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if callResp.Error != nil {
-		return "0", fmt.Errorf("RPC error: %s", callResp.Error.Message)
-	}
-
-	// Convert hex to decimal
-	if callResp.Result == "0x" || callResp.Result == "" {
-		return "0", nil
-	}
-
-	balance := new(big.Int)
-	balance.SetString(callResp.Result[2:], 16) // Remove 0x prefix
-	
-	return balance.String(), nil
-}
-
 // getTransactionsPublicRPC handles transaction fetching for public RPC endpoints
 func (c *AlchemyClient) getTransactionsPublicRPC(ctx context.Context, address string, chainID int, baseURL string) ([]*models.Transaction, error) {
 	// For Polygon Amoy, we'll use Polygonscan API since standard RPC doesn't provide transaction history
@@ -719,4 +651,173 @@ func (c *AlchemyClient) getTransactionsFromPolygonscan(ctx context.Context, addr
 		"transactionCount", len(transactions))
 
 	return transactions, nil
-}
\ No newline at end of file
+}
+
+// ErrReceiptNotFound is returned by GetTransactionReceipt for transactions that haven't been mined
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// TransactionReceipt holds the gas accounting and inclusion fields of a mined transaction
+type TransactionReceipt struct {
+	GasUsed           int64
+	EffectiveGasPrice string // wei, base 10
+	// L1Fee is the L1 data fee rollups charge on top of gas, in wei, base 10; empty on
+	// chains whose receipts don't report one
+	L1Fee       string
+	Status      string
+	BlockNumber int64
+	BlockHash   string
+}
+
+// GetTransactionReceipt fetches gas usage and effective gas price for a transaction
+func (c *AlchemyClient) GetTransactionReceipt(ctx context.Context, hash string, chainID int) (*TransactionReceipt, error) {
+	baseURL, exists := c.baseURL(chainID)
+	if !exists {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	reqBody := map[string]interface{}{
+		"id":      1,
+		"jsonrpc": "2.0",
+		"method":  "eth_getTransactionReceipt",
+		"params":  []interface{}{hash},
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var receiptResp struct {
+		Result *struct {
+			GasUsed           string `json:"gasUsed"`
+			EffectiveGasPrice string `json:"effectiveGasPrice"`
+			L1Fee             string `json:"l1Fee"`
+			Status            string `json:"status"`
+			BlockNumber       string `json:"blockNumber"`
+			BlockHash         string `json:"blockHash"`
+		} `json:"result"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&receiptResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if receiptResp.Error != nil {
+		return nil, fmt.Errorf("alchemy API error: %s", receiptResp.Error.Message)
+	}
+
+	if receiptResp.Result == nil {
+		return nil, fmt.Errorf("%w for %s", ErrReceiptNotFound, hash)
+	}
+
+	gasUsed, err := hexutil.DecodeInt64(receiptResp.Result.GasUsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gasUsed %q: %w", receiptResp.Result.GasUsed, err)
+	}
+
+	gasPrice, err := hexutil.DecodeBig(receiptResp.Result.EffectiveGasPrice)
+	if err != nil {
+		return nil, fmt.Errorf("invalid effectiveGasPrice %q: %w", receiptResp.Result.EffectiveGasPrice, err)
+	}
+
+	var l1Fee string
+	if receiptResp.Result.L1Fee != "" {
+		fee, err := hexutil.DecodeBig(receiptResp.Result.L1Fee)
+		if err != nil {
+			return nil, fmt.Errorf("invalid l1Fee %q: %w", receiptResp.Result.L1Fee, err)
+		}
+		l1Fee = fee.String()
+	}
+
+	status := "success"
+	if receiptResp.Result.Status == "0x0" {
+		status = "failed"
+	}
+
+	blockNumber, err := hexutil.DecodeInt64(receiptResp.Result.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blockNumber %q: %w", receiptResp.Result.BlockNumber, err)
+	}
+
+	return &TransactionReceipt{
+		GasUsed:           gasUsed,
+		EffectiveGasPrice: gasPrice.String(),
+		L1Fee:             l1Fee,
+		Status:            status,
+		BlockNumber:       blockNumber,
+		BlockHash:         strings.ToLower(receiptResp.Result.BlockHash),
+	}, nil
+}
+
+// GetGasPrice returns the node's current gas price suggestion in wei
+func (c *AlchemyClient) GetGasPrice(ctx context.Context, chainID int) (*big.Int, error) {
+	baseURL, exists := c.baseURL(chainID)
+	if !exists {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	reqBody := map[string]interface{}{
+		"id":      1,
+		"jsonrpc": "2.0",
+		"method":  "eth_gasPrice",
+		"params":  []interface{}{},
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var gasResp struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&gasResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if gasResp.Error != nil {
+		return nil, fmt.Errorf("alchemy API error: %s", gasResp.Error.Message)
+	}
+
+	gasPrice, err := hexutil.DecodeBig(gasResp.Result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas price %q: %w", gasResp.Result, err)
+	}
+
+	return gasPrice, nil
+}
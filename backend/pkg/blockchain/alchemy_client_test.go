@@ -0,0 +1,95 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWallet = "0x8ba1f109551bd432803012645ac136ddd64dba72"
+
+// newTestAlchemyClient serves mainnet from the cassette
+func newTestAlchemyClient(t *testing.T, cassette string) *AlchemyClient {
+	server := vcr.Replay(t, cassette)
+	return &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}
+}
+
+func TestAlchemyGetTokenBalances(t *testing.T) {
+	client := newTestAlchemyClient(t, "testdata/alchemy/token_balances.json")
+
+	balances, err := client.GetTokenBalances(context.Background(), testWallet, 1)
+	require.NoError(t, err)
+
+	// Padded zero balances and failed reads are skipped without a metadata lookup
+	require.Len(t, balances, 3)
+
+	// The native balance comes first, as the chain's native token
+	assert.Equal(t, NativeTokenAddress, balances[0].Token.Address)
+	assert.True(t, balances[0].Token.IsNative())
+	assert.Equal(t, "ETH", balances[0].Token.Symbol)
+	assert.Equal(t, 18, balances[0].Token.Decimals)
+	assert.Equal(t, "1234567890123456789", balances[0].Balance)
+
+	assert.Equal(t, "USDC", balances[1].Token.Symbol)
+	assert.Equal(t, 6, balances[1].Token.Decimals)
+	assert.Equal(t, "2500000000", balances[1].Balance)
+	require.NotNil(t, balances[1].Token.LogoURI)
+	assert.Equal(t, "https://static.alchemyapi.io/images/assets/3408.png", *balances[1].Token.LogoURI)
+
+	assert.Equal(t, "0x514910771af9ca656af840dff83e8264ecf986ca", balances[2].Token.Address)
+	assert.Equal(t, "Chainlink", balances[2].Token.Name)
+	assert.Equal(t, 18, balances[2].Token.Decimals)
+	assert.Equal(t, "12500000000000000000", balances[2].Balance)
+}
+
+func TestAlchemyGetETHBalance(t *testing.T) {
+	client := newTestAlchemyClient(t, "testdata/alchemy/eth_balance.json")
+	ctx := context.Background()
+
+	balance, err := client.GetETHBalance(ctx, testWallet, 1)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1234567890123456789), balance)
+
+	_, err = client.GetETHBalance(ctx, testWallet, 1)
+	assert.ErrorContains(t, err, "alchemy API error: Your app has exceeded its compute units per second capacity")
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = client.GetETHBalance(ctx, testWallet, 56)
+	assert.EqualError(t, err, "unsupported chain ID: 56")
+}
+
+func TestAlchemyGetTransactionsInRange(t *testing.T) {
+	client := newTestAlchemyClient(t, "testdata/alchemy/asset_transfers.json")
+
+	// Outgoing transfers span two pages, and the swap shows up in both directions
+	txs, err := client.GetTransactionsInRange(context.Background(), testWallet, 1, BlockRange{From: 19000000})
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	assert.Equal(t, "0x1d2e3f405162738495a6b7c8d9e0f1021324354657687980a1b2c3d4e5f60718", txs[0].Hash)
+	assert.Equal(t, "erc20", txs[0].Type)
+	assert.Equal(t, "0x12a05f200", *txs[0].Value)
+	require.NotNil(t, txs[0].BlockNumber)
+	assert.Equal(t, int64(19000005), *txs[0].BlockNumber)
+	assert.Equal(t, time.Date(2024, 1, 14, 8, 5, 47, 0, time.UTC), txs[0].Timestamp.UTC())
+
+	assert.Equal(t, "0x4f1c8a7be1f6a9d5b2c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5", txs[1].Hash)
+	assert.Equal(t, "USDC", txs[1].Metadata["asset"], "the outgoing leg of the swap is kept")
+	assert.Equal(t, testWallet, txs[1].FromAddress)
+
+	assert.Equal(t, "external", txs[2].Type)
+	assert.Equal(t, "ETH", txs[2].Metadata["asset"])
+	assert.Equal(t, "success", txs[2].Status)
+}
+
+func TestAlchemyGetTransactionsInRangeError(t *testing.T) {
+	client := newTestAlchemyClient(t, "testdata/alchemy/asset_transfers_error.json")
+
+	_, err := client.GetTransactionsInRange(context.Background(), testWallet, 1, BlockRange{From: 20, To: 10})
+	assert.EqualError(t, err, "failed to get outgoing transfers: alchemy API error: invalid 'fromBlock' param: fromBlock must be before toBlock")
+}
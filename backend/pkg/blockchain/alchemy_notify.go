@@ -0,0 +1,264 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	addr "github.com/defi-dashboard/backend/pkg/address"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/defi-dashboard/backend/pkg/hexutil"
+)
+
+// AlchemyNotifyURL is the Notify API, which manages the addresses a webhook watches
+const AlchemyNotifyURL = "https://dashboard.alchemy.com/api"
+
+// Alchemy webhook types handled
+const (
+	AlchemyWebhookAddressActivity  = "ADDRESS_ACTIVITY"
+	AlchemyWebhookMinedTransaction = "MINED_TRANSACTION"
+)
+
+// maxAlchemyWebhookAddressUpdate is the most addresses sent in one update; larger
+// changes are split
+const maxAlchemyWebhookAddressUpdate = 500
+
+// alchemyNetworks maps Alchemy's network names onto chain IDs
+var alchemyNetworks = map[string]int{
+	"ETH_MAINNET":   1,
+	"OPT_MAINNET":   10,
+	"MATIC_MAINNET": 137,
+	"ARB_MAINNET":   42161,
+	"MATIC_AMOY":    80002,
+}
+
+// AlchemyWebhook is a Notify webhook set up in the Alchemy dashboard for a chain. Its
+// signing key signs every delivery.
+type AlchemyWebhook struct {
+	ID         string
+	ChainID    int
+	SigningKey string
+}
+
+// AlchemyWebhookEvent is a delivery of an address activity or mined transaction
+// webhook, reduced to what's needed to find the wallets it concerns
+type AlchemyWebhookEvent struct {
+	ID        string
+	WebhookID string
+	Type      string
+	ChainID   int
+	// Addresses are the senders and recipients involved, normalized and deduplicated
+	Addresses []string
+	// FromBlock and ToBlock bound the blocks the activity was mined in; both are 0 for
+	// activity seen before it was mined
+	FromBlock int64
+	ToBlock   int64
+}
+
+type alchemyWebhookPayload struct {
+	WebhookID string `json:"webhookId"`
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Event     struct {
+		Network  string `json:"network"`
+		Activity []struct {
+			FromAddress string `json:"fromAddress"`
+			ToAddress   string `json:"toAddress"`
+			BlockNum    string `json:"blockNum"`
+			Hash        string `json:"hash"`
+		} `json:"activity"`
+		Transaction *struct {
+			From        string `json:"from"`
+			To          string `json:"to"`
+			BlockNumber string `json:"blockNumber"`
+			Hash        string `json:"hash"`
+		} `json:"transaction"`
+	} `json:"event"`
+}
+
+// VerifyAlchemySignature reports whether signature, the X-Alchemy-Signature header, is
+// the hex HMAC-SHA256 of the raw body under the webhook's signing key
+func VerifyAlchemySignature(body []byte, signature, signingKey string) bool {
+	if signingKey == "" {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// AlchemyWebhookID returns the ID of the webhook a delivery claims to come from, so its
+// signing key can be found before the body is trusted
+func AlchemyWebhookID(body []byte) (string, error) {
+	var payload struct {
+		WebhookID string `json:"webhookId"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if payload.WebhookID == "" {
+		return "", errors.New("webhook payload has no webhookId")
+	}
+	return payload.WebhookID, nil
+}
+
+// ParseAlchemyWebhook parses an address activity or mined transaction delivery. Other
+// webhook types are rejected.
+func ParseAlchemyWebhook(body []byte) (*AlchemyWebhookEvent, error) {
+	var payload alchemyWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if payload.ID == "" || payload.WebhookID == "" {
+		return nil, errors.New("webhook payload has no id or webhookId")
+	}
+	chainID, ok := alchemyNetworks[payload.Event.Network]
+	if !ok {
+		return nil, fmt.Errorf("unsupported network %q", payload.Event.Network)
+	}
+
+	event := &AlchemyWebhookEvent{
+		ID:        payload.ID,
+		WebhookID: payload.WebhookID,
+		Type:      payload.Type,
+		ChainID:   chainID,
+	}
+	addresses := make(map[string]bool)
+	add := func(from, to, blockNum string) error {
+		for _, a := range []string{from, to} {
+			if a != "" {
+				addresses[addr.Normalize(a)] = true
+			}
+		}
+		if blockNum == "" {
+			return nil
+		}
+		block, err := hexutil.DecodeInt64(blockNum)
+		if err != nil {
+			return fmt.Errorf("invalid block number %q: %w", blockNum, err)
+		}
+		if event.FromBlock == 0 || block < event.FromBlock {
+			event.FromBlock = block
+		}
+		if block > event.ToBlock {
+			event.ToBlock = block
+		}
+		return nil
+	}
+
+	switch payload.Type {
+	case AlchemyWebhookAddressActivity:
+		for _, activity := range payload.Event.Activity {
+			if err := add(activity.FromAddress, activity.ToAddress, activity.BlockNum); err != nil {
+				return nil, err
+			}
+		}
+	case AlchemyWebhookMinedTransaction:
+		tx := payload.Event.Transaction
+		if tx == nil {
+			return nil, errors.New("mined transaction payload has no transaction")
+		}
+		if err := add(tx.From, tx.To, tx.BlockNumber); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported webhook type %q", payload.Type)
+	}
+
+	for a := range addresses {
+		event.Addresses = append(event.Addresses, a)
+	}
+	sort.Strings(event.Addresses)
+	return event, nil
+}
+
+// AlchemyNotifyClient adds and removes the addresses Notify webhooks watch
+type AlchemyNotifyClient struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewAlchemyNotifyClient authenticates with the auth token shown on the Notify dashboard
+func NewAlchemyNotifyClient(token string) *AlchemyNotifyClient {
+	return &AlchemyNotifyClient{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		baseURL: AlchemyNotifyURL,
+		token:   token,
+	}
+}
+
+// UpdateWebhookAddresses starts the webhook watching the added addresses and stops it
+// watching the removed ones
+func (c *AlchemyNotifyClient) UpdateWebhookAddresses(ctx context.Context, webhookID string, added, removed []string) error {
+	for len(added) > 0 || len(removed) > 0 {
+		addNow, removeNow := added, removed
+		if len(addNow) > maxAlchemyWebhookAddressUpdate {
+			addNow = addNow[:maxAlchemyWebhookAddressUpdate]
+		}
+		if room := maxAlchemyWebhookAddressUpdate - len(addNow); len(removeNow) > room {
+			removeNow = removeNow[:room]
+		}
+		if err := c.updateWebhookAddresses(ctx, webhookID, addNow, removeNow); err != nil {
+			return err
+		}
+		added, removed = added[len(addNow):], removed[len(removeNow):]
+	}
+	return nil
+}
+
+func (c *AlchemyNotifyClient) updateWebhookAddresses(ctx context.Context, webhookID string, added, removed []string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"webhook_id":          webhookID,
+		"addresses_to_add":    nonNil(added),
+		"addresses_to_remove": nonNil(removed),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.baseURL+"/update-webhook-addresses", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Alchemy-Token", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return alchemyError(resp.StatusCode, 0, fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(message))))
+	}
+	return nil
+}
+
+// nonNil keeps empty lists as [] rather than null in request bodies
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,163 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const addressActivityPayload = `{
+	"webhookId": "wh_octjglnywaupz6th",
+	"id": "whevt_ogrc5v64myey69ux",
+	"createdAt": "2024-02-28T17:48:53.306Z",
+	"type": "ADDRESS_ACTIVITY",
+	"event": {
+		"network": "ETH_MAINNET",
+		"activity": [
+			{
+				"category": "external",
+				"fromAddress": "0x8BA1F109551BD432803012645AC136DDD64DBA72",
+				"toAddress": "0x59479de9d374bdbcba6c791e5d036591976fe425",
+				"blockNum": "0xdf34a3",
+				"hash": "0x7a4a39da2a3fa1fc2ef88fd1eaea070286ed2aba21e0419dcfb6d5c5d9f02a72",
+				"value": 0.5,
+				"asset": "ETH"
+			},
+			{
+				"category": "token",
+				"fromAddress": "0x59479de9d374bdbcba6c791e5d036591976fe425",
+				"toAddress": "0x8ba1f109551bd432803012645ac136ddd64dba72",
+				"blockNum": "0xdf34a1",
+				"hash": "0x5a4a39da2a3fa1fc2ef88fd1eaea070286ed2aba21e0419dcfb6d5c5d9f02a72",
+				"rawContract": {"rawValue": "0x0de0b6b3a7640000", "address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}
+			}
+		]
+	}
+}`
+
+func signAlchemyPayload(body, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyAlchemySignature(t *testing.T) {
+	body := []byte(addressActivityPayload)
+	signature := signAlchemyPayload(addressActivityPayload, "whsec_test")
+
+	assert.True(t, VerifyAlchemySignature(body, signature, "whsec_test"))
+	assert.False(t, VerifyAlchemySignature(body, signature, "whsec_other"))
+	assert.False(t, VerifyAlchemySignature(append(body, ' '), signature, "whsec_test"))
+	assert.False(t, VerifyAlchemySignature(body, "not-hex", "whsec_test"))
+	assert.False(t, VerifyAlchemySignature(body, signature, ""))
+}
+
+func TestParseAlchemyWebhook(t *testing.T) {
+	t.Run("address activity", func(t *testing.T) {
+		event, err := ParseAlchemyWebhook([]byte(addressActivityPayload))
+		require.NoError(t, err)
+
+		assert.Equal(t, "whevt_ogrc5v64myey69ux", event.ID)
+		assert.Equal(t, "wh_octjglnywaupz6th", event.WebhookID)
+		assert.Equal(t, AlchemyWebhookAddressActivity, event.Type)
+		assert.Equal(t, 1, event.ChainID)
+		assert.Equal(t, []string{
+			"0x59479de9d374bdbcba6c791e5d036591976fe425",
+			"0x8ba1f109551bd432803012645ac136ddd64dba72",
+		}, event.Addresses)
+		assert.Equal(t, int64(0xdf34a1), event.FromBlock)
+		assert.Equal(t, int64(0xdf34a3), event.ToBlock)
+	})
+
+	t.Run("mined transaction", func(t *testing.T) {
+		event, err := ParseAlchemyWebhook([]byte(`{
+			"webhookId": "wh_mined", "id": "whevt_mined", "type": "MINED_TRANSACTION",
+			"event": {"network": "MATIC_MAINNET", "transaction": {
+				"from": "0x8ba1f109551bd432803012645ac136ddd64dba72", "to": null,
+				"blockNumber": "0x10", "hash": "0xabc"
+			}}
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, 137, event.ChainID)
+		assert.Equal(t, []string{"0x8ba1f109551bd432803012645ac136ddd64dba72"}, event.Addresses)
+		assert.Equal(t, int64(16), event.FromBlock)
+		assert.Equal(t, int64(16), event.ToBlock)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"not json":        `{`,
+			"no id":           `{"webhookId": "wh", "type": "ADDRESS_ACTIVITY", "event": {"network": "ETH_MAINNET"}}`,
+			"unknown network": `{"webhookId": "wh", "id": "e", "type": "ADDRESS_ACTIVITY", "event": {"network": "SOL_MAINNET"}}`,
+			"unknown type":    `{"webhookId": "wh", "id": "e", "type": "NFT_ACTIVITY", "event": {"network": "ETH_MAINNET"}}`,
+			"bad block":       `{"webhookId": "wh", "id": "e", "type": "ADDRESS_ACTIVITY", "event": {"network": "ETH_MAINNET", "activity": [{"blockNum": "xyz"}]}}`,
+		} {
+			_, err := ParseAlchemyWebhook([]byte(body))
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestAlchemyWebhookID(t *testing.T) {
+	id, err := AlchemyWebhookID([]byte(addressActivityPayload))
+	require.NoError(t, err)
+	assert.Equal(t, "wh_octjglnywaupz6th", id)
+
+	_, err = AlchemyWebhookID([]byte(`{"id": "whevt"}`))
+	assert.Error(t, err)
+}
+
+func TestAlchemyNotifyUpdateWebhookAddresses(t *testing.T) {
+	type update struct {
+		WebhookID string   `json:"webhook_id"`
+		Add       []string `json:"addresses_to_add"`
+		Remove    []string `json:"addresses_to_remove"`
+	}
+	var updates []update
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/update-webhook-addresses", r.URL.Path)
+		assert.Equal(t, "notify-token", r.Header.Get("X-Alchemy-Token"))
+		var u update
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&u))
+		updates = append(updates, u)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	client := &AlchemyNotifyClient{httpClient: server.Client(), baseURL: server.URL, token: "notify-token"}
+
+	added := make([]string, maxAlchemyWebhookAddressUpdate+10)
+	for i := range added {
+		added[i] = "0xadd"
+	}
+	err := client.UpdateWebhookAddresses(context.Background(), "wh_1", added, []string{"0xremoved"})
+	require.NoError(t, err)
+
+	// Updates are split to stay within the limit, removals filling the last one
+	require.Len(t, updates, 2)
+	assert.Equal(t, "wh_1", updates[0].WebhookID)
+	assert.Len(t, updates[0].Add, maxAlchemyWebhookAddressUpdate)
+	assert.Empty(t, updates[0].Remove)
+	assert.Len(t, updates[1].Add, 10)
+	assert.Equal(t, []string{"0xremoved"}, updates[1].Remove)
+}
+
+func TestAlchemyNotifyUpdateWebhookAddressesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	client := &AlchemyNotifyClient{httpClient: server.Client(), baseURL: server.URL}
+
+	err := client.UpdateWebhookAddresses(context.Background(), "wh_1", []string{"0xadd"}, nil)
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/defi-dashboard/backend/pkg/hexutil"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// maxAssetTransferPages bounds how many pages of alchemy_getAssetTransfers one query
+// follows; callers needing more should narrow the block range
+const maxAssetTransferPages = 20
+
+// BlockRange bounds a transfer query. A zero From starts at genesis and a zero To ends at
+// the latest block; both ends are inclusive.
+type BlockRange struct {
+	From int64
+	To   int64
+}
+
+// filter returns the block bounds as alchemy_getAssetTransfers parameters
+func (r BlockRange) filter() map[string]interface{} {
+	filter := make(map[string]interface{})
+	if r.From > 0 {
+		filter["fromBlock"] = fmt.Sprintf("0x%x", r.From)
+	}
+	if r.To > 0 {
+		filter["toBlock"] = fmt.Sprintf("0x%x", r.To)
+	}
+	return filter
+}
+
+// getAssetTransfers runs alchemy_getAssetTransfers with the given filter merged into the
+// defaults, following pageKey until the last page or maxAssetTransferPages
+func (c *AlchemyClient) getAssetTransfers(ctx context.Context, baseURL string, filter map[string]interface{}) ([]TransferData, error) {
+	params := map[string]interface{}{
+		"fromBlock":    "0x0",
+		"toBlock":      "latest",
+		"withMetadata": true,
+		"maxCount":     "0x3e8", // 1000 transfers per page
+	}
+	for k, v := range filter {
+		params[k] = v
+	}
+
+	var transfers []TransferData
+	for page := 0; ; page++ {
+		if page == maxAssetTransferPages {
+			logger.Warn("Asset transfers truncated at page limit", "pages", maxAssetTransferPages, "transfers", len(transfers))
+			break
+		}
+
+		result, err := c.getAssetTransfersPage(ctx, baseURL, params)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, result.Result.Transfers...)
+		if result.Result.PageKey == "" {
+			break
+		}
+		params["pageKey"] = result.Result.PageKey
+	}
+
+	return transfers, nil
+}
+
+// getAssetTransfersPage fetches a single page of alchemy_getAssetTransfers
+func (c *AlchemyClient) getAssetTransfersPage(ctx context.Context, baseURL string, params map[string]interface{}) (*AlchemyTransactionResponse, error) {
+	reqBody := map[string]interface{}{
+		"id":      1,
+		"jsonrpc": "2.0",
+		"method":  "alchemy_getAssetTransfers",
+		"params":  []map[string]interface{}{params},
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var txResp AlchemyTransactionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&txResp); err != nil {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, alchemyError(resp.StatusCode, 0, resp.Status)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if txResp.Error != nil {
+		return nil, alchemyError(resp.StatusCode, txResp.Error.Code, txResp.Error.Message)
+	}
+
+	return &txResp, nil
+}
+
+// mergeTransfers combines transfer lists into one ordered by block, keeping the first
+// transfer seen for each transaction hash
+func mergeTransfers(lists ...[]TransferData) []TransferData {
+	seen := make(map[string]bool)
+	var merged []TransferData
+	for _, list := range lists {
+		for _, transfer := range list {
+			key := strings.ToLower(transfer.Hash)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, transfer)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, _ := hexutil.DecodeInt64(merged[i].BlockNum)
+		b, _ := hexutil.DecodeInt64(merged[j].BlockNum)
+		return a < b
+	})
+	return merged
+}
@@ -0,0 +1,35 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockRangeFilter(t *testing.T) {
+	assert.Empty(t, BlockRange{}.filter())
+	assert.Equal(t, map[string]interface{}{"fromBlock": "0x12d687"}, BlockRange{From: 1234567}.filter())
+	assert.Equal(t, map[string]interface{}{"fromBlock": "0xa", "toBlock": "0xff"}, BlockRange{From: 10, To: 255}.filter())
+}
+
+func TestMergeTransfers(t *testing.T) {
+	outgoing := []TransferData{
+		{Hash: "0xswap", BlockNum: "0x20", Asset: "USDC"},
+		{Hash: "0xsend", BlockNum: "0x05", Asset: "ETH"},
+		{Hash: "0xself", BlockNum: "0x10", Asset: "ETH"},
+	}
+	incoming := []TransferData{
+		{Hash: "0xSWAP", BlockNum: "0x20", Asset: "WETH"},
+		{Hash: "0xself", BlockNum: "0x10", Asset: "ETH"},
+		{Hash: "0xreceive", BlockNum: "0x01", Asset: "DAI"},
+	}
+
+	merged := mergeTransfers(outgoing, incoming)
+	var hashes []string
+	for _, transfer := range merged {
+		hashes = append(hashes, transfer.Hash)
+	}
+	assert.Equal(t, []string{"0xreceive", "0xsend", "0xself", "0xswap"}, hashes)
+	assert.Equal(t, "USDC", merged[3].Asset, "the first transfer seen for a hash is kept")
+	assert.Empty(t, mergeTransfers(nil, nil))
+}
@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bech32Charset maps 5-bit values to the characters of a bech32 data part
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	checksum := uint32(1)
+	for _, v := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				checksum ^= bech32Generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// bech32 checksum constants: BIP-173 bech32 and BIP-350 bech32m
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// DecodeBech32 splits a bech32 string into its human-readable prefix and payload bytes,
+// verifying the checksum
+func DecodeBech32(s string) (string, []byte, error) {
+	hrp, data, checksum, err := bech32Decode(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if checksum != bech32Const {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+
+	payload, err := convertBits(data, 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, payload, nil
+}
+
+// EncodeBech32 encodes payload bytes under a human-readable prefix
+func EncodeBech32(hrp string, payload []byte) (string, error) {
+	data, err := convertBits(payload, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, data, bech32Const), nil
+}
+
+// decodeSegwitAddress decodes a segwit address into its witness version and program.
+// Version 0 uses the bech32 checksum and later versions bech32m.
+func decodeSegwitAddress(hrp, address string) (byte, []byte, error) {
+	gotHRP, data, checksum, err := bech32Decode(address)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gotHRP != hrp {
+		return 0, nil, fmt.Errorf("unexpected segwit prefix %q", gotHRP)
+	}
+	if len(data) < 1 || data[0] > 16 {
+		return 0, nil, fmt.Errorf("invalid witness version")
+	}
+
+	version := data[0]
+	if (version == 0 && checksum != bech32Const) || (version > 0 && checksum != bech32mConst) {
+		return 0, nil, fmt.Errorf("invalid segwit checksum")
+	}
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(program) < 2 || len(program) > 40 || (version == 0 && len(program) != 20 && len(program) != 32) {
+		return 0, nil, fmt.Errorf("invalid witness program length")
+	}
+	return version, program, nil
+}
+
+// encodeSegwitAddress encodes a witness version and program as a segwit address
+func encodeSegwitAddress(hrp string, version byte, program []byte) (string, error) {
+	data, err := convertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	checksum := uint32(bech32Const)
+	if version > 0 {
+		checksum = bech32mConst
+	}
+	return bech32Encode(hrp, append([]byte{version}, data...), checksum), nil
+}
+
+// bech32Decode splits a bech32 or bech32m string into its prefix and 5-bit data, without
+// the checksum, returning which checksum constant it verifies against
+func bech32Decode(s string) (string, []byte, uint32, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, fmt.Errorf("mixed case bech32 string")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) || len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("invalid bech32 string length")
+	}
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 prefix character")
+		}
+	}
+
+	data := make([]byte, 0, len(s)-sep-1)
+	for _, c := range s[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(v))
+	}
+
+	checksum := bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	if checksum != bech32Const && checksum != bech32mConst {
+		return "", nil, 0, fmt.Errorf("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-6], checksum, nil
+}
+
+// bech32Encode appends the checksum to 5-bit data and encodes it under the prefix
+func bech32Encode(hrp string, data []byte, checksum uint32) string {
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ checksum
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range data {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		b.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+// convertBits regroups a byte slice from fromBits-wide to toBits-wide values
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var (
+		acc    uint32
+		bits   uint
+		result []byte
+	)
+	maxValue := uint32(1)<<toBits - 1
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid %d-bit value %d", fromBits, v)
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			result = append(result, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil, fmt.Errorf("invalid bech32 padding")
+	}
+	return result, nil
+}
@@ -0,0 +1,389 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	bitcoinSegwitHRP = "bc"
+	p2pkhVersion     = 0x00
+	p2shVersion      = 0x05
+
+	// BitcoinGapLimit is how many consecutive unused addresses end an xpub scan (BIP-44)
+	BitcoinGapLimit = 20
+	// bitcoinMaxScanAddresses bounds the scan of each xpub chain
+	bitcoinMaxScanAddresses = 1000
+)
+
+// Script types an extended public key's addresses are derived as
+const (
+	BitcoinScriptP2PKH      = "p2pkh"
+	BitcoinScriptP2SHP2WPKH = "p2sh-p2wpkh"
+	BitcoinScriptP2WPKH     = "p2wpkh"
+)
+
+// extendedKeyVersions maps mainnet extended public key prefixes to their script type
+var extendedKeyVersions = map[[4]byte]string{
+	{0x04, 0x88, 0xb2, 0x1e}: BitcoinScriptP2PKH,      // xpub (BIP-44)
+	{0x04, 0x9d, 0x7c, 0xb2}: BitcoinScriptP2SHP2WPKH, // ypub (BIP-49)
+	{0x04, 0xb2, 0x47, 0x46}: BitcoinScriptP2WPKH,     // zpub (BIP-84)
+}
+
+// ExtendedPublicKey is a BIP-32 extended public key. Only non-hardened children can be
+// derived from it, which is all address discovery needs.
+type ExtendedPublicKey struct {
+	ScriptType string
+	chainCode  []byte
+	publicKey  []byte // Compressed SEC encoding
+}
+
+// ParseExtendedPublicKey decodes a mainnet xpub, ypub or zpub
+func ParseExtendedPublicKey(s string) (*ExtendedPublicKey, error) {
+	data, err := base58CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 78 {
+		return nil, fmt.Errorf("invalid extended key length %d", len(data))
+	}
+
+	var version [4]byte
+	copy(version[:], data[:4])
+	scriptType, ok := extendedKeyVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported extended key version %x", version)
+	}
+	if data[45] != 0x02 && data[45] != 0x03 {
+		return nil, fmt.Errorf("extended key is not a public key")
+	}
+	if _, err := crypto.DecompressPubkey(data[45:78]); err != nil {
+		return nil, fmt.Errorf("invalid extended public key: %w", err)
+	}
+
+	return &ExtendedPublicKey{
+		ScriptType: scriptType,
+		chainCode:  data[13:45],
+		publicKey:  data[45:78],
+	}, nil
+}
+
+// Child derives the non-hardened child key at index
+func (k *ExtendedPublicKey) Child(index uint32) (*ExtendedPublicKey, error) {
+	if index >= 1<<31 {
+		return nil, fmt.Errorf("hardened child %d can't be derived from a public key", index)
+	}
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(k.publicKey)
+	binary.Write(mac, binary.BigEndian, index)
+	sum := mac.Sum(nil)
+
+	curve := crypto.S256()
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid child %d", index)
+	}
+	parent, err := crypto.DecompressPubkey(k.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	tx, ty := curve.ScalarBaseMult(sum[:32])
+	x, y := curve.Add(parent.X, parent.Y, tx, ty)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, fmt.Errorf("invalid child %d", index)
+	}
+
+	return &ExtendedPublicKey{
+		ScriptType: k.ScriptType,
+		chainCode:  sum[32:],
+		publicKey:  crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}),
+	}, nil
+}
+
+// Address encodes the key as an address of its script type
+func (k *ExtendedPublicKey) Address() (string, error) {
+	keyHash := hash160(k.publicKey)
+	switch k.ScriptType {
+	case BitcoinScriptP2PKH:
+		return base58CheckEncode(append([]byte{p2pkhVersion}, keyHash...)), nil
+	case BitcoinScriptP2SHP2WPKH:
+		redeemScript := append([]byte{0x00, 0x14}, keyHash...)
+		return base58CheckEncode(append([]byte{p2shVersion}, hash160(redeemScript)...)), nil
+	case BitcoinScriptP2WPKH:
+		return encodeSegwitAddress(bitcoinSegwitHRP, 0, keyHash)
+	default:
+		return "", fmt.Errorf("unsupported script type %s", k.ScriptType)
+	}
+}
+
+// DeriveAddress returns the address at chain/index below the key: chain 0 holds
+// receiving addresses and chain 1 change addresses
+func (k *ExtendedPublicKey) DeriveAddress(chain, index uint32) (string, error) {
+	chainKey, err := k.Child(chain)
+	if err != nil {
+		return "", err
+	}
+	child, err := chainKey.Child(index)
+	if err != nil {
+		return "", err
+	}
+	return child.Address()
+}
+
+// ValidateBitcoinAddress checks a mainnet P2PKH, P2SH or segwit address
+func ValidateBitcoinAddress(address string) error {
+	if strings.HasPrefix(strings.ToLower(address), bitcoinSegwitHRP+"1") {
+		_, _, err := decodeSegwitAddress(bitcoinSegwitHRP, address)
+		return err
+	}
+
+	payload, err := base58CheckDecode(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 || (payload[0] != p2pkhVersion && payload[0] != p2shVersion) {
+		return fmt.Errorf("not a mainnet Bitcoin address")
+	}
+	return nil
+}
+
+// BitcoinAccountKind returns whether identifier is a Bitcoin address or an extended
+// public key, or an error when it is neither
+func BitcoinAccountKind(identifier string) (string, error) {
+	if ValidateBitcoinAddress(identifier) == nil {
+		return models.BitcoinAccountAddress, nil
+	}
+	if _, err := ParseExtendedPublicKey(identifier); err == nil {
+		return models.BitcoinAccountXpub, nil
+	}
+	return "", fmt.Errorf("not a Bitcoin address or extended public key")
+}
+
+// IsBitcoinAccount reports whether identifier is a Bitcoin address or extended public key
+func IsBitcoinAccount(identifier string) bool {
+	_, err := BitcoinAccountKind(identifier)
+	return err == nil
+}
+
+// BitcoinAccountState is a tracked account's addresses with history and live balance
+type BitcoinAccountState struct {
+	Addresses   []string
+	BalanceSats int64
+}
+
+// ScanBitcoinAccount reads an address's balance or, for an extended public key, walks its
+// receiving and change chains until BitcoinGapLimit consecutive addresses are unused
+func ScanBitcoinAccount(ctx context.Context, client *external.EsploraClient, identifier string) (*BitcoinAccountState, error) {
+	state := &BitcoinAccountState{}
+	lookup := func(address string) (bool, error) {
+		info, err := client.GetAddress(ctx, address)
+		if err != nil {
+			return false, fmt.Errorf("failed to read address %s: %w", address, err)
+		}
+		if info.Used() {
+			state.Addresses = append(state.Addresses, address)
+			state.BalanceSats += info.BalanceSats()
+		}
+		return info.Used(), nil
+	}
+
+	if ValidateBitcoinAddress(identifier) == nil {
+		if _, err := lookup(identifier); err != nil {
+			return nil, err
+		}
+		// A fresh address is still the account's only address
+		if len(state.Addresses) == 0 {
+			state.Addresses = []string{identifier}
+		}
+		return state, nil
+	}
+
+	key, err := ParseExtendedPublicKey(identifier)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range []uint32{0, 1} {
+		if err := scanBitcoinChain(key, chain, lookup); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// scanBitcoinChain derives addresses along one chain of key until BitcoinGapLimit
+// consecutive addresses are reported unused by lookup
+func scanBitcoinChain(key *ExtendedPublicKey, chain uint32, lookup func(address string) (bool, error)) error {
+	chainKey, err := key.Child(chain)
+	if err != nil {
+		return err
+	}
+
+	unused := 0
+	for index := uint32(0); index < bitcoinMaxScanAddresses && unused < BitcoinGapLimit; index++ {
+		child, err := chainKey.Child(index)
+		if err != nil {
+			// Skipped per BIP-32; astronomically unlikely
+			continue
+		}
+		address, err := child.Address()
+		if err != nil {
+			return err
+		}
+		used, err := lookup(address)
+		if err != nil {
+			return err
+		}
+		if used {
+			unused = 0
+		} else {
+			unused++
+		}
+	}
+	return nil
+}
+
+// GetBitcoinTransactions lists the confirmed transactions touching an account's addresses
+// as their net effect on the account, oldest first. Listing stops at a transaction known
+// already tells it the rest of the address's history is stored.
+func GetBitcoinTransactions(ctx context.Context, client *external.EsploraClient, addresses []string, known map[string]bool) ([]*models.BitcoinTransaction, error) {
+	owned := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		owned[address] = true
+	}
+
+	seen := make(map[string]bool)
+	var result []*models.BitcoinTransaction
+	for _, address := range addresses {
+		lastSeen := ""
+		for {
+			page, err := client.GetAddressTransactions(ctx, address, lastSeen)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list transactions of %s: %w", address, err)
+			}
+
+			reachedKnown := false
+			for _, tx := range page {
+				if known[tx.Txid] {
+					reachedKnown = true
+					break
+				}
+				if seen[tx.Txid] || !tx.Status.Confirmed {
+					continue
+				}
+				seen[tx.Txid] = true
+				result = append(result, bitcoinTransaction(tx, owned))
+			}
+
+			if reachedKnown || len(page) < external.EsploraPageSize {
+				break
+			}
+			lastSeen = page[len(page)-1].Txid
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].BlockHeight < result[j].BlockHeight
+	})
+	return result, nil
+}
+
+// bitcoinTransaction nets a transaction's outputs to owned addresses against the owned
+// outputs it spends. The fee is the account's when it funded the transaction.
+func bitcoinTransaction(tx external.EsploraTransaction, owned map[string]bool) *models.BitcoinTransaction {
+	var received, spent int64
+	for _, in := range tx.Vin {
+		if in.Prevout != nil && owned[in.Prevout.Address] {
+			spent += in.Prevout.Value
+		}
+	}
+	for _, out := range tx.Vout {
+		if owned[out.Address] {
+			received += out.Value
+		}
+	}
+
+	result := &models.BitcoinTransaction{
+		ID:          uuid.New(),
+		Txid:        tx.Txid,
+		BlockHeight: tx.Status.BlockHeight,
+		Timestamp:   time.Unix(tx.Status.BlockTime, 0).UTC(),
+		AmountSats:  received - spent,
+	}
+	if spent > 0 {
+		result.FeeSats = tx.Fee
+	}
+	return result
+}
+
+// BitcoinToken returns bitcoin as a token, priced when price is known
+func BitcoinToken(price *float64) *models.Token {
+	chain := models.BitcoinChain
+	return &models.Token{
+		ID:       uuid.New(),
+		Address:  models.BTCTokenAddress,
+		Chain:    &chain,
+		Symbol:   "BTC",
+		Name:     "Bitcoin",
+		Decimals: 8,
+		PriceUSD: price,
+	}
+}
+
+// BitcoinBalance builds a portfolio balance of sats, valued at price when known
+func BitcoinBalance(sats int64, price *float64) *models.Balance {
+	token := BitcoinToken(price)
+	balance := &models.Balance{
+		ID:       uuid.New(),
+		WalletID: uuid.New(),
+		TokenID:  token.ID,
+		Token:    token,
+		Balance:  fmt.Sprint(sats),
+	}
+	if price != nil {
+		value := SatsToBTC(sats) * *price
+		balance.BalanceUSD = &value
+	}
+	return balance
+}
+
+// SatsToBTC converts satoshis to BTC
+func SatsToBTC(sats int64) float64 {
+	return amounts.Float64(big.NewInt(sats), amounts.BitcoinDecimals)
+}
+
+// GetBTCPriceUSD returns the current BTC price from CoinGecko
+func (s *BlockchainService) GetBTCPriceUSD(ctx context.Context) (float64, error) {
+	prices, err := s.coinGeckoClient.GetTokenPrices(ctx, []string{"bitcoin"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get BTC price: %w", err)
+	}
+	price, ok := prices["bitcoin"]
+	if !ok {
+		return 0, fmt.Errorf("no BTC price returned")
+	}
+	return price.USD, nil
+}
+
+func hash160(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	hasher := ripemd160.New()
+	hasher.Write(sum[:])
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBitcoinAddress(t *testing.T) {
+	valid := []string{
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",                             // P2PKH
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",                             // P2SH
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",                     // P2WPKH (BIP-173)
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", // P2TR (BIP-350)
+	}
+	for _, address := range valid {
+		assert.NoError(t, ValidateBitcoinAddress(address), address)
+	}
+
+	invalid := []string{
+		"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMJ",         // bad checksum
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", // bad checksum
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", // v1 with a bech32 checksum
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", // testnet
+		"0x1234567890123456789012345678901234567890",
+	}
+	for _, address := range invalid {
+		assert.Error(t, ValidateBitcoinAddress(address), address)
+	}
+}
+
+func TestSegwitProgram(t *testing.T) {
+	version, program, err := decodeSegwitAddress("bc", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4")
+	require.NoError(t, err)
+	assert.Equal(t, byte(0), version)
+	assert.Equal(t, "751e76e8199196d454941c45d1b3a323f1433bd6", hex.EncodeToString(program))
+
+	address, err := encodeSegwitAddress("bc", 0, program)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", address)
+}
+
+func TestExtendedPublicKeyDerivation(t *testing.T) {
+	// BIP-84 test vector, account m/84'/0'/0'
+	key, err := ParseExtendedPublicKey("zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs")
+	require.NoError(t, err)
+	assert.Equal(t, BitcoinScriptP2WPKH, key.ScriptType)
+
+	address, err := key.DeriveAddress(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", address)
+
+	address, err = key.DeriveAddress(0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", address)
+
+	address, err = key.DeriveAddress(1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", address)
+
+	_, err = key.Child(1 << 31)
+	assert.Error(t, err, "hardened")
+}
+
+func TestBitcoinAccountKind(t *testing.T) {
+	kind, err := BitcoinAccountKind("1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH")
+	require.NoError(t, err)
+	assert.Equal(t, models.BitcoinAccountAddress, kind)
+
+	kind, err = BitcoinAccountKind("zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs")
+	require.NoError(t, err)
+	assert.Equal(t, models.BitcoinAccountXpub, kind)
+
+	_, err = BitcoinAccountKind("cosmos1qypqxpq9qcrsszg2pvxq6rs0zqg3yyc5lzv7xu")
+	assert.Error(t, err)
+}
+
+func TestScanBitcoinChain(t *testing.T) {
+	key, err := ParseExtendedPublicKey("zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs")
+	require.NoError(t, err)
+
+	// Addresses 0 and 3 are used; the scan continues for a full gap after the last one
+	used := map[string]bool{}
+	for _, index := range []uint32{0, 3} {
+		address, err := key.DeriveAddress(0, index)
+		require.NoError(t, err)
+		used[address] = true
+	}
+
+	looked := 0
+	err = scanBitcoinChain(key, 0, func(address string) (bool, error) {
+		looked++
+		return used[address], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4+BitcoinGapLimit, looked)
+}
+
+func TestBitcoinTransactionNet(t *testing.T) {
+	owned := map[string]bool{"bc1qmine": true, "bc1qchange": true}
+
+	var tx external.EsploraTransaction
+	tx.Txid = "abc"
+	tx.Fee = 500
+	tx.Status.Confirmed = true
+	tx.Status.BlockHeight = 800000
+	tx.Status.BlockTime = 1690000000
+	tx.Vin = append(tx.Vin, struct {
+		Prevout    *external.EsploraOutput `json:"prevout"`
+		IsCoinbase bool                    `json:"is_coinbase"`
+	}{Prevout: &external.EsploraOutput{Address: "bc1qmine", Value: 100000}})
+	tx.Vout = []external.EsploraOutput{
+		{Address: "bc1qsomeoneelse", Value: 60000},
+		{Address: "bc1qchange", Value: 39500},
+	}
+
+	// Sent 60000 to someone else and paid the 500 fee
+	result := bitcoinTransaction(tx, owned)
+	assert.Equal(t, int64(-60500), result.AmountSats)
+	assert.Equal(t, int64(500), result.FeeSats)
+	assert.Equal(t, int64(800000), result.BlockHeight)
+
+	// The recipient's view: received, no fee
+	result = bitcoinTransaction(tx, map[string]bool{"bc1qsomeoneelse": true})
+	assert.Equal(t, int64(60000), result.AmountSats)
+	assert.Equal(t, int64(0), result.FeeSats)
+}
+
+func TestBitcoinBalance(t *testing.T) {
+	price := 50000.0
+	balance := BitcoinBalance(150000000, &price)
+	assert.Equal(t, "150000000", balance.Balance)
+	assert.Equal(t, models.BitcoinChain, *balance.Token.Chain)
+	require.NotNil(t, balance.BalanceUSD)
+	assert.InDelta(t, 75000.0, *balance.BalanceUSD, 1e-6)
+
+	assert.Nil(t, BitcoinBalance(1, nil).BalanceUSD)
+}
@@ -0,0 +1,322 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/hexutil"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// defaultReorgDepth is the reorg window for chains without an entry in reorgDepths
+const defaultReorgDepth = 64
+
+// reorgDepths is how many blocks behind the head each chain can still reorg.
+// Records older than this are final and don't need their block hash tracked.
+var reorgDepths = map[int]int64{
+	1:     64,  // Ethereum finalizes after two epochs
+	137:   256, // Polygon PoS sees the deepest reorgs of the supported chains
+	42161: 64,
+	10:    64,
+	80002: 256,
+}
+
+// ReorgDepth returns how many blocks behind the head a chain can still reorg
+func ReorgDepth(chainID int) int64 {
+	if depth, ok := reorgDepths[chainID]; ok {
+		return depth
+	}
+	return defaultReorgDepth
+}
+
+// defaultFinalityThreshold is the confirmations required on chains without an entry in finalityThresholds
+const defaultFinalityThreshold = 12
+
+var (
+	finalityMu sync.RWMutex
+	// finalityThresholds is how many confirmations a transaction needs before it is shown as confirmed
+	finalityThresholds = map[int]int64{
+		1:     12,
+		137:   128,
+		42161: 20,
+		10:    20,
+		80002: 128,
+	}
+)
+
+// SetFinalityThresholds overrides the confirmations required per chain; chains not in the map keep their threshold
+func SetFinalityThresholds(thresholds map[int]int64) {
+	finalityMu.Lock()
+	defer finalityMu.Unlock()
+	for chainID, threshold := range thresholds {
+		finalityThresholds[chainID] = threshold
+	}
+}
+
+// FinalityThreshold returns how many confirmations a transaction on the chain needs to be final
+func FinalityThreshold(chainID int) int64 {
+	finalityMu.RLock()
+	defer finalityMu.RUnlock()
+	if threshold, ok := finalityThresholds[chainID]; ok {
+		return threshold
+	}
+	return defaultFinalityThreshold
+}
+
+// ErrBlockNotFound is returned for blocks the chain hasn't produced
+var ErrBlockNotFound = errors.New("block not found")
+
+// BlockHeader is the subset of a block needed to detect reorgs and date the state read at it
+type BlockHeader struct {
+	Number     int64
+	Hash       string
+	ParentHash string
+	Timestamp  time.Time
+}
+
+// blockTag is the JSON-RPC block parameter for a block number, or for the latest block if nil
+func blockTag(number *int64) string {
+	if number == nil {
+		return "latest"
+	}
+	return "0x" + strconv.FormatInt(*number, 16)
+}
+
+// GetBlockHeader returns the header of the given block, or of the latest block if number is nil
+func (c *AlchemyClient) GetBlockHeader(ctx context.Context, chainID int, number *int64) (*BlockHeader, error) {
+	baseURL, exists := c.baseURL(chainID)
+	if !exists {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	tag := blockTag(number)
+
+	reqBody := map[string]interface{}{
+		"id":      1,
+		"jsonrpc": "2.0",
+		"method":  "eth_getBlockByNumber",
+		"params":  []interface{}{tag, false},
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var blockResp struct {
+		Result *struct {
+			Number     string `json:"number"`
+			Hash       string `json:"hash"`
+			ParentHash string `json:"parentHash"`
+			Timestamp  string `json:"timestamp"`
+		} `json:"result"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&blockResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if blockResp.Error != nil {
+		return nil, fmt.Errorf("alchemy API error: %s", blockResp.Error.Message)
+	}
+	if blockResp.Result == nil {
+		return nil, fmt.Errorf("block %s: %w", tag, ErrBlockNotFound)
+	}
+
+	blockNumber, err := hexutil.DecodeInt64(blockResp.Result.Number)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number %q: %w", blockResp.Result.Number, err)
+	}
+
+	header := &BlockHeader{
+		Number:     blockNumber,
+		Hash:       strings.ToLower(blockResp.Result.Hash),
+		ParentHash: strings.ToLower(blockResp.Result.ParentHash),
+	}
+	if blockResp.Result.Timestamp != "" {
+		timestamp, err := hexutil.DecodeInt64(blockResp.Result.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block timestamp %q: %w", blockResp.Result.Timestamp, err)
+		}
+		header.Timestamp = time.Unix(timestamp, 0).UTC()
+	}
+	return header, nil
+}
+
+// GetBlock returns the header of the given block, or of the chain head if number is nil
+func (s *BlockchainService) GetBlock(ctx context.Context, chainID int, number *int64) (*BlockHeader, error) {
+	return s.alchemyClient.GetBlockHeader(ctx, chainID, number)
+}
+
+// GetLatestBlockNumber returns the chain head
+func (s *BlockchainService) GetLatestBlockNumber(ctx context.Context, chainID int) (int64, error) {
+	header, err := s.alchemyClient.GetBlockHeader(ctx, chainID, nil)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number, nil
+}
+
+// GetBlockHash returns the canonical hash of a block
+func (s *BlockchainService) GetBlockHash(ctx context.Context, chainID int, blockNumber int64) (string, error) {
+	header, err := s.alchemyClient.GetBlockHeader(ctx, chainID, &blockNumber)
+	if err != nil {
+		return "", err
+	}
+	return header.Hash, nil
+}
+
+// blockHashesInWindow returns the hashes of the blocks still inside the reorg window,
+// fetching each block once. Older blocks are final and are left out, as are blocks
+// whose hash can't be fetched.
+func (s *BlockchainService) blockHashesInWindow(ctx context.Context, chainID int, numbers []int64) map[int64]string {
+	hashes := make(map[int64]string)
+	if len(numbers) == 0 {
+		return hashes
+	}
+
+	head, err := s.GetLatestBlockNumber(ctx, chainID)
+	if err != nil {
+		logger.Warn("Failed to fetch chain head", "chainID", chainID, "error", err)
+		return hashes
+	}
+	oldest := head - ReorgDepth(chainID)
+
+	failed := make(map[int64]bool)
+	for _, number := range numbers {
+		if number <= oldest || failed[number] {
+			continue
+		}
+		if _, ok := hashes[number]; ok {
+			continue
+		}
+		hash, err := s.GetBlockHash(ctx, chainID, number)
+		if err != nil {
+			logger.Warn("Failed to fetch block hash", "chainID", chainID, "block", number, "error", err)
+			failed[number] = true
+			continue
+		}
+		hashes[number] = hash
+	}
+	return hashes
+}
+
+// fillNFTBlockHashes records the block hash of transfers still inside the reorg window
+func (s *BlockchainService) fillNFTBlockHashes(ctx context.Context, chainID int, transfers []*models.NFTTransfer) {
+	numbers := make([]int64, 0, len(transfers))
+	for _, t := range transfers {
+		numbers = append(numbers, t.BlockNumber)
+	}
+	hashes := s.blockHashesInWindow(ctx, chainID, numbers)
+	for _, t := range transfers {
+		if hash, ok := hashes[t.BlockNumber]; ok {
+			t.BlockHash = &hash
+		}
+	}
+}
+
+// fillTransactionBlockHashes records the block hash of mined transactions still inside
+// the reorg window
+func (s *BlockchainService) fillTransactionBlockHashes(ctx context.Context, chainID int, transactions []*models.Transaction) {
+	numbers := make([]int64, 0, len(transactions))
+	for _, tx := range transactions {
+		if tx.BlockNumber != nil {
+			numbers = append(numbers, *tx.BlockNumber)
+		}
+	}
+	hashes := s.blockHashesInWindow(ctx, chainID, numbers)
+	for _, tx := range transactions {
+		if tx.BlockNumber == nil {
+			continue
+		}
+		if hash, ok := hashes[*tx.BlockNumber]; ok {
+			tx.BlockHash = &hash
+		}
+	}
+}
+
+// IsTransactionKnown reports whether the node knows the transaction, mined or still in its mempool
+func (c *AlchemyClient) IsTransactionKnown(ctx context.Context, hash string, chainID int) (bool, error) {
+	baseURL, exists := c.baseURL(chainID)
+	if !exists {
+		return false, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	reqBody := map[string]interface{}{
+		"id":      1,
+		"jsonrpc": "2.0",
+		"method":  "eth_getTransactionByHash",
+		"params":  []interface{}{hash},
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(string(reqBytes)))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var txResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&txResp); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if txResp.Error != nil {
+		return false, fmt.Errorf("alchemy API error: %s", txResp.Error.Message)
+	}
+
+	return len(txResp.Result) > 0 && string(txResp.Result) != "null", nil
+}
+
+// GetTransactionReceipt returns the receipt of a mined transaction, or ErrReceiptNotFound if it isn't mined
+func (s *BlockchainService) GetTransactionReceipt(ctx context.Context, chainID int, hash string) (*TransactionReceipt, error) {
+	return s.alchemyClient.GetTransactionReceipt(ctx, hash, chainID)
+}
+
+// IsTransactionKnown reports whether a transaction is mined or waiting in the mempool
+func (s *BlockchainService) IsTransactionKnown(ctx context.Context, chainID int, hash string) (bool, error) {
+	return s.alchemyClient.IsTransactionKnown(ctx, hash, chainID)
+}
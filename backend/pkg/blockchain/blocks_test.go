@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": nil}
+		if req.Params[0] != "0xffffff" {
+			response["result"] = map[string]string{
+				"number":     "0x64",
+				"hash":       "0xABC",
+				"parentHash": "0xDEF",
+				"timestamp":  "0x65df6f35",
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+	service := &BlockchainService{alchemyClient: &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}}
+	ctx := context.Background()
+
+	header, err := service.GetBlock(ctx, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), header.Number)
+	assert.Equal(t, "0xabc", header.Hash)
+	assert.Equal(t, time.Date(2024, 2, 28, 17, 36, 53, 0, time.UTC), header.Timestamp)
+
+	future := int64(0xffffff)
+	_, err = service.GetBlock(ctx, 1, &future)
+	assert.ErrorIs(t, err, ErrBlockNotFound)
+}
+
+func TestFillTransactionBlockHashes(t *testing.T) {
+	head := int64(1000)
+	fetched := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		tag := req.Params[0].(string)
+		fetched[tag]++
+		number := head
+		if tag != "latest" {
+			n, err := strconv.ParseInt(tag[2:], 16, 64)
+			require.NoError(t, err)
+			number = n
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": map[string]string{
+			"number":     "0x" + strconv.FormatInt(number, 16),
+			"hash":       "0xh" + strconv.FormatInt(number, 10),
+			"parentHash": "0x0",
+			"timestamp":  "0x65df6f35",
+		}})
+	}))
+	defer server.Close()
+	service := &BlockchainService{alchemyClient: &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}}
+
+	block := func(n int64) *int64 { return &n }
+	recent, final := head-2, head-ReorgDepth(1)
+	transactions := []*models.Transaction{
+		{Hash: "0x01", BlockNumber: block(recent)},
+		{Hash: "0x02", BlockNumber: block(recent)},
+		{Hash: "0x03", BlockNumber: block(final)},
+		{Hash: "0x04"}, // pending
+	}
+	service.fillTransactionBlockHashes(context.Background(), 1, transactions)
+
+	require.NotNil(t, transactions[0].BlockHash)
+	assert.Equal(t, "0xh998", *transactions[0].BlockHash)
+	assert.Equal(t, transactions[0].BlockHash, transactions[1].BlockHash)
+	// Final blocks can't be reorged, so their hash isn't fetched
+	assert.Nil(t, transactions[2].BlockHash)
+	assert.Nil(t, transactions[3].BlockHash)
+	assert.Equal(t, map[string]int{"latest": 1, "0x3e6": 1}, fetched)
+}
@@ -0,0 +1,188 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/hexutil"
+	"github.com/google/uuid"
+)
+
+// builtinChains are the chains supported in code; custom chains can't replace them
+var builtinChains = []int{ChainIDEthereum, ChainIDPolygon, ChainIDArbitrum, ChainIDOptimism, ChainIDPolygonAmoy}
+
+// builtinTestnets are the built-in chains whose balances have no value
+var builtinTestnets = map[int]bool{ChainIDPolygonAmoy: true}
+
+// testnetMode is set in environments run against testnets, where testnet balances count
+// towards portfolio totals by default
+var testnetMode struct {
+	sync.RWMutex
+	enabled bool
+}
+
+// SetTestnetMode sets whether testnets are included in totals when a request doesn't say
+func SetTestnetMode(enabled bool) {
+	testnetMode.Lock()
+	defer testnetMode.Unlock()
+	testnetMode.enabled = enabled
+}
+
+// TestnetMode reports whether testnets are included in totals by default
+func TestnetMode() bool {
+	testnetMode.RLock()
+	defer testnetMode.RUnlock()
+	return testnetMode.enabled
+}
+
+// IsTestnet reports whether a chain is a testnet, built-in or registered as one
+func IsTestnet(chainID int) bool {
+	if builtinTestnets[chainID] {
+		return true
+	}
+	chain, ok := LookupCustomChain(chainID)
+	return ok && chain.IsTestnet
+}
+
+// AccountChain returns the chain an account identifier outside the EVM belongs to, told
+// by its format: a Cosmos address names its chain in its bech32 prefix, and Bitcoin
+// addresses and extended public keys are on Bitcoin. It returns false for anything
+// else, which is taken for an EVM address on whichever chain the caller picks.
+func AccountChain(identifier string) (models.ChainRef, bool) {
+	if chain, ok := CosmosChainForAddress(identifier); ok {
+		return chain.Ref(), true
+	}
+	if IsBitcoinAccount(identifier) {
+		return models.BitcoinChain, true
+	}
+	return models.ChainRef{}, false
+}
+
+// SupportedChainsFor returns the supported chains, leaving testnets out unless
+// includeTestnets is set
+func SupportedChainsFor(includeTestnets bool) []int {
+	var chains []int
+	for _, chainID := range GetSupportedChains() {
+		if includeTestnets || !IsTestnet(chainID) {
+			chains = append(chains, chainID)
+		}
+	}
+	return chains
+}
+
+// customChains is the registry of chains added by configuration, shared by every client
+var customChains struct {
+	sync.RWMutex
+	byID map[int]models.CustomChain
+}
+
+// SetCustomChains replaces the registry of custom chains. Chains with the ID of a
+// built-in chain are ignored.
+func SetCustomChains(chains []*models.CustomChain) {
+	byID := make(map[int]models.CustomChain, len(chains))
+	for _, chain := range chains {
+		if !IsBuiltinChain(chain.ChainID) {
+			byID[chain.ChainID] = *chain
+		}
+	}
+
+	customChains.Lock()
+	defer customChains.Unlock()
+	customChains.byID = byID
+}
+
+// LookupCustomChain returns the custom chain registered with an ID
+func LookupCustomChain(chainID int) (models.CustomChain, bool) {
+	customChains.RLock()
+	defer customChains.RUnlock()
+	chain, ok := customChains.byID[chainID]
+	return chain, ok
+}
+
+// IsSupportedChain reports whether a chain is built in or registered as a custom chain
+func IsSupportedChain(chainID int) bool {
+	if IsBuiltinChain(chainID) {
+		return true
+	}
+	_, ok := LookupCustomChain(chainID)
+	return ok
+}
+
+// IsBuiltinChain reports whether a chain is supported in code
+func IsBuiltinChain(chainID int) bool {
+	for _, id := range builtinChains {
+		if id == chainID {
+			return true
+		}
+	}
+	return false
+}
+
+// NativeTokenAddress is the pseudo-address a chain's native asset is listed under
+// among token balances and in the tokens table
+const NativeTokenAddress = "0x0000000000000000000000000000000000000000"
+
+// nativeAssets are the symbols and names of the built-in chains' gas tokens
+var nativeAssets = map[int]struct{ symbol, name string }{
+	ChainIDEthereum:    {"ETH", "Ether"},
+	ChainIDPolygon:     {"MATIC", "Polygon"},
+	ChainIDArbitrum:    {"ETH", "Ether"},
+	ChainIDOptimism:    {"ETH", "Ether"},
+	ChainIDPolygonAmoy: {"MATIC", "Polygon"},
+}
+
+// NativeToken returns the pseudo-token a chain's native asset is listed as among its
+// balances: the zero address, with the symbol registered for the chain. Chains not
+// known are assumed to use ether.
+func NativeToken(chainID int) *models.Token {
+	symbol, name := "ETH", "Ether"
+	if asset, ok := nativeAssets[chainID]; ok {
+		symbol, name = asset.symbol, asset.name
+	} else if chain, ok := LookupCustomChain(chainID); ok {
+		symbol, name = chain.NativeSymbol, chain.NativeSymbol
+	}
+
+	return &models.Token{
+		ID:       uuid.New(),
+		Address:  NativeTokenAddress,
+		ChainID:  chainID,
+		Symbol:   symbol,
+		Name:     name,
+		Decimals: amounts.NativeDecimals(models.EVMChain(chainID)),
+	}
+}
+
+// customChainIDs returns the IDs of the registered custom chains in ascending order
+func customChainIDs() []int {
+	customChains.RLock()
+	defer customChains.RUnlock()
+	ids := make([]int, 0, len(customChains.byID))
+	for id := range customChains.byID {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// FetchChainID asks an RPC endpoint which chain it serves, so a custom chain can't be
+// registered against an endpoint for another chain
+func FetchChainID(ctx context.Context, rpcURL string) (int64, error) {
+	responses := make([]rpcResponse, 1)
+	if err := NewAlchemyClient("").sendBatch(ctx, rpcURL, 0, []rpcRequest{{Method: "eth_chainId"}}, responses); err != nil {
+		return 0, err
+	}
+	if responses[0].Error != nil {
+		return 0, responses[0].Error
+	}
+
+	var result string
+	if err := json.Unmarshal(responses[0].Result, &result); err != nil {
+		return 0, fmt.Errorf("invalid eth_chainId result: %w", err)
+	}
+	return hexutil.DecodeInt64(result)
+}
@@ -0,0 +1,156 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomChainRegistry(t *testing.T) {
+	coinGeckoID := "mantle"
+	SetCustomChains([]*models.CustomChain{
+		{ChainID: 5000, Name: "Mantle", RPCURL: "https://rpc.mantle.xyz", NativeSymbol: "MNT", NativeCoinGeckoID: &coinGeckoID},
+		{ChainID: 324, Name: "zkSync Era", RPCURL: "https://mainnet.era.zksync.io", NativeSymbol: "ETH"},
+		{ChainID: ChainIDEthereum, Name: "Not Ethereum", RPCURL: "https://example.com", NativeSymbol: "XYZ"},
+	})
+	defer SetCustomChains(nil)
+
+	assert.Equal(t, []int{ChainIDEthereum, ChainIDPolygon, ChainIDArbitrum, ChainIDOptimism, ChainIDPolygonAmoy, 324, 5000}, GetSupportedChains())
+	assert.Equal(t, "Mantle", GetChainName(5000))
+	assert.Equal(t, "Ethereum", GetChainName(ChainIDEthereum), "custom chains can't replace built-in ones")
+	assert.Equal(t, "Chain 999", GetChainName(999))
+
+	assert.Equal(t, "mantle", NativeTokenCoinGeckoID(5000))
+	assert.Equal(t, "ethereum", NativeTokenCoinGeckoID(324), "chains without a gas token ID fall back to ether")
+
+	client := NewAlchemyClient("key")
+	url, ok := client.baseURL(5000)
+	require.True(t, ok)
+	assert.Equal(t, "https://rpc.mantle.xyz", url)
+	url, ok = client.baseURL(ChainIDEthereum)
+	require.True(t, ok)
+	assert.Equal(t, AlchemyMainnetURL+"/key", url)
+
+	token := NativeToken(5000)
+	assert.Equal(t, "MNT", token.Symbol)
+	id, ok := tokenCoinGeckoID(token)
+	require.True(t, ok)
+	assert.Equal(t, "mantle", id)
+
+	SetCustomChains(nil)
+	_, ok = client.baseURL(5000)
+	assert.False(t, ok)
+	assert.Equal(t, builtinChains, GetSupportedChains())
+}
+
+func TestNativeToken(t *testing.T) {
+	tests := []struct {
+		chainID     int
+		symbol      string
+		coinGeckoID string
+	}{
+		{ChainIDEthereum, "ETH", "ethereum"},
+		{ChainIDPolygon, "MATIC", "matic-network"},
+		{ChainIDArbitrum, "ETH", "ethereum"},
+		{ChainIDOptimism, "ETH", "ethereum"},
+		{ChainIDPolygonAmoy, "MATIC", "matic-network"},
+	}
+	for _, tt := range tests {
+		token := NativeToken(tt.chainID)
+		assert.Equal(t, NativeTokenAddress, token.Address)
+		assert.Equal(t, tt.chainID, token.ChainID)
+		assert.Equal(t, tt.symbol, token.Symbol)
+		assert.Equal(t, 18, token.Decimals)
+		assert.True(t, token.IsNative())
+
+		id, ok := tokenCoinGeckoID(token)
+		require.True(t, ok)
+		assert.Equal(t, tt.coinGeckoID, id, "native tokens are priced by their chain, not their symbol")
+	}
+}
+
+func TestCustomChainNativeBalance(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		require.Len(t, batch, 1, "custom chains have no token indexer")
+		assert.Equal(t, "eth_getBalance", batch[0]["method"])
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"id": batch[0]["id"], "jsonrpc": "2.0", "result": "0xde0b6b3a7640000"},
+		})
+	}))
+	defer server.Close()
+
+	SetCustomChains([]*models.CustomChain{{ChainID: 5000, Name: "Mantle", RPCURL: server.URL, NativeSymbol: "MNT"}})
+	defer SetCustomChains(nil)
+
+	client := &AlchemyClient{httpClient: server.Client()}
+	balances, err := client.GetTokenBalances(context.Background(), "0x0000000000000000000000000000000000000001", 5000)
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, "1000000000000000000", balances[0].Balance)
+	assert.Equal(t, "MNT", balances[0].Token.Symbol)
+	assert.True(t, balances[0].Token.IsNative())
+}
+
+func TestFetchChainID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		require.Len(t, batch, 1)
+		assert.Equal(t, "eth_chainId", batch[0]["method"])
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"id": batch[0]["id"], "jsonrpc": "2.0", "result": "0x" + big.NewInt(5000).Text(16)},
+		})
+	}))
+	defer server.Close()
+
+	chainID, err := FetchChainID(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), chainID)
+}
+
+func TestTestnetChains(t *testing.T) {
+	SetCustomChains([]*models.CustomChain{
+		{ChainID: 5000, Name: "Mantle", RPCURL: "https://rpc.mantle.xyz", NativeSymbol: "MNT"},
+		{ChainID: 5003, Name: "Mantle Sepolia", RPCURL: "https://rpc.sepolia.mantle.xyz", NativeSymbol: "MNT", IsTestnet: true},
+	})
+	defer SetCustomChains(nil)
+
+	assert.True(t, IsTestnet(ChainIDPolygonAmoy))
+	assert.True(t, IsTestnet(5003))
+	assert.False(t, IsTestnet(5000))
+	assert.False(t, IsTestnet(ChainIDEthereum))
+
+	assert.Equal(t, []int{ChainIDEthereum, ChainIDPolygon, ChainIDArbitrum, ChainIDOptimism, 5000}, SupportedChainsFor(false))
+	assert.Equal(t, GetSupportedChains(), SupportedChainsFor(true))
+
+	assert.False(t, TestnetMode())
+	SetTestnetMode(true)
+	defer SetTestnetMode(false)
+	assert.True(t, TestnetMode())
+}
+
+func TestAccountChain(t *testing.T) {
+	payload := make([]byte, 20)
+	osmo, err := EncodeBech32("osmo", payload)
+	require.NoError(t, err)
+
+	chain, ok := AccountChain(osmo)
+	require.True(t, ok)
+	assert.Equal(t, "cosmos:osmosis-1", chain.String())
+
+	chain, ok = AccountChain("1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH")
+	require.True(t, ok)
+	assert.Equal(t, models.BitcoinChain, chain)
+
+	_, ok = AccountChain("0x1234567890123456789012345678901234567890")
+	assert.False(t, ok)
+}
\ No newline at end of file
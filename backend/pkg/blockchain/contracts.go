@@ -0,0 +1,21 @@
+package blockchain
+
+import "context"
+
+// CallContract runs a read-only call of data on to. from sets the caller for contracts
+// that check msg.sender and may be empty.
+func (s *BlockchainService) CallContract(ctx context.Context, chainID int, from, to string, data []byte) ([]byte, error) {
+	return s.alchemyClient.ethCallFrom(ctx, chainID, from, to, data)
+}
+
+// Multicall runs read-only calls in as few eth_calls as Multicall3 allows; calls fail
+// individually without failing the batch
+func (s *BlockchainService) Multicall(ctx context.Context, chainID int, calls []Call) ([]CallResult, error) {
+	return s.alchemyClient.multicall(ctx, chainID, calls)
+}
+
+// GetTokenMetadata returns the decimals, name and symbol of ERC-20 tokens keyed by
+// lowercase address, from the metadata cache first. Tokens without metadata are omitted.
+func (s *BlockchainService) GetTokenMetadata(ctx context.Context, chainID int, addresses []string) (map[string]TokenMetadata, error) {
+	return s.alchemyClient.getTokenMetadata(ctx, addresses, chainID)
+}
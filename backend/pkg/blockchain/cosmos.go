@@ -0,0 +1,281 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Cosmos SDK chain IDs
+const (
+	ChainIDCosmosHub = "cosmoshub-4"
+	ChainIDOsmosis   = "osmosis-1"
+)
+
+// CosmosChain describes a Cosmos SDK chain tracked alongside the EVM chains
+type CosmosChain struct {
+	ChainID      string
+	Name         string
+	Bech32Prefix string
+	StakingDenom string
+	LCDURL       string
+}
+
+// Ref returns the chain's CAIP-2 identifier
+func (c CosmosChain) Ref() models.ChainRef {
+	return models.ChainRef{Namespace: models.ChainNamespaceCosmos, Reference: c.ChainID}
+}
+
+var cosmosChains = []CosmosChain{
+	{
+		ChainID:      ChainIDCosmosHub,
+		Name:         "Cosmos Hub",
+		Bech32Prefix: "cosmos",
+		StakingDenom: "uatom",
+		LCDURL:       "https://cosmos-rest.publicnode.com",
+	},
+	{
+		ChainID:      ChainIDOsmosis,
+		Name:         "Osmosis",
+		Bech32Prefix: "osmo",
+		StakingDenom: "uosmo",
+		LCDURL:       "https://osmosis-rest.publicnode.com",
+	},
+}
+
+// cosmosAsset is the display metadata of a base denom
+type cosmosAsset struct {
+	symbol   string
+	name     string
+	decimals int
+}
+
+// cosmosAssets are the base denoms shown with a symbol, whether held natively or over IBC.
+// Other denoms are listed as-is and left unpriced.
+var cosmosAssets = map[string]cosmosAsset{
+	"uatom": {symbol: "ATOM", name: "Cosmos Hub", decimals: 6},
+	"uosmo": {symbol: "OSMO", name: "Osmosis", decimals: 6},
+	"uusdc": {symbol: "USDC", name: "USD Coin", decimals: 6},
+	"utia":  {symbol: "TIA", name: "Celestia", decimals: 6},
+}
+
+// GetCosmosChains returns the supported Cosmos SDK chains
+func GetCosmosChains() []CosmosChain {
+	return cosmosChains
+}
+
+// CosmosChainForAddress returns the supported chain whose bech32 prefix the address uses
+func CosmosChainForAddress(address string) (CosmosChain, bool) {
+	hrp, payload, err := DecodeBech32(address)
+	if err != nil || (len(payload) != 20 && len(payload) != 32) {
+		return CosmosChain{}, false
+	}
+	for _, chain := range cosmosChains {
+		if chain.Bech32Prefix == hrp {
+			return chain, true
+		}
+	}
+	return CosmosChain{}, false
+}
+
+// IsCosmosAddress reports whether the address belongs to a supported Cosmos SDK chain
+func IsCosmosAddress(address string) bool {
+	_, ok := CosmosChainForAddress(address)
+	return ok
+}
+
+// CosmosAddressOn re-encodes an address under another chain's prefix. The supported chains
+// share coin type 118, so one key controls the same account on each of them.
+func CosmosAddressOn(address string, chain CosmosChain) (string, error) {
+	_, payload, err := DecodeBech32(address)
+	if err != nil {
+		return "", err
+	}
+	return EncodeBech32(chain.Bech32Prefix, payload)
+}
+
+// CosmosHoldings are an address's liquid balances and delegations on one Cosmos SDK chain
+type CosmosHoldings struct {
+	Chain       models.ChainRef
+	Balances    []*models.Balance
+	Delegations []*models.CosmosDelegation
+	// BalancesValue is the liquid balances' worth; TotalValue adds stake and unclaimed rewards
+	BalancesValue float64
+	TotalValue    float64
+}
+
+// GetCosmosHoldings reads an address's bank balances, delegations and unclaimed staking
+// rewards from the chain's LCD endpoint and values them in USD
+func (s *BlockchainService) GetCosmosHoldings(ctx context.Context, address string, chain CosmosChain) (*CosmosHoldings, error) {
+	client := external.NewCosmosClient(chain.LCDURL)
+
+	coins, err := client.GetBalances(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s balances: %w", chain.Name, err)
+	}
+	delegations, err := client.GetDelegations(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s delegations: %w", chain.Name, err)
+	}
+	rewards, err := client.GetRewards(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s staking rewards: %w", chain.Name, err)
+	}
+
+	resolver := &cosmosTokenResolver{client: client, chain: chain, tokens: make(map[string]*models.Token)}
+	holdings := &CosmosHoldings{Chain: chain.Ref()}
+
+	for _, coin := range coins {
+		if coin.Amount == "" || coin.Amount == "0" {
+			continue
+		}
+		token := resolver.token(ctx, coin.Denom)
+		holdings.Balances = append(holdings.Balances, &models.Balance{
+			ID:       uuid.New(),
+			WalletID: uuid.New(),
+			TokenID:  token.ID,
+			Token:    token,
+			Balance:  coin.Amount,
+		})
+	}
+
+	stakingToken := resolver.token(ctx, chain.StakingDenom)
+	holdings.Delegations = cosmosDelegations(chain, stakingToken, delegations, rewards)
+
+	// Stake and rewards are priced like balances of the staking token
+	valued := make([]*models.Balance, 0, len(holdings.Balances)+2*len(holdings.Delegations))
+	valued = append(valued, holdings.Balances...)
+	staked := make([]*models.Balance, 0, 2*len(holdings.Delegations))
+	for _, delegation := range holdings.Delegations {
+		staked = append(staked,
+			&models.Balance{Token: stakingToken, Balance: delegation.Amount},
+			&models.Balance{Token: stakingToken, Balance: delegation.Rewards})
+	}
+	valued = append(valued, staked...)
+
+	if _, err := s.enrichBalancesWithPrices(ctx, valued); err != nil {
+		logger.Error("Failed to enrich Cosmos balances with prices", "error", err, "chain", chain.ChainID)
+	}
+
+	for _, balance := range holdings.Balances {
+		if balance.BalanceUSD != nil {
+			holdings.BalancesValue += *balance.BalanceUSD
+		}
+	}
+	holdings.TotalValue = holdings.BalancesValue
+	for i, delegation := range holdings.Delegations {
+		delegation.AmountUSD = staked[2*i].BalanceUSD
+		delegation.RewardsUSD = staked[2*i+1].BalanceUSD
+		if delegation.AmountUSD != nil {
+			holdings.TotalValue += *delegation.AmountUSD
+		}
+		if delegation.RewardsUSD != nil {
+			holdings.TotalValue += *delegation.RewardsUSD
+		}
+	}
+
+	return holdings, nil
+}
+
+// cosmosDelegations pairs each delegation with its unclaimed rewards in the staking denom.
+// Rewards left with validators no longer delegated to are listed with a zero amount.
+func cosmosDelegations(chain CosmosChain, stakingToken *models.Token, delegations []external.CosmosDelegation, rewards []external.CosmosValidatorRewards) []*models.CosmosDelegation {
+	unclaimed := make(map[string]*big.Int, len(rewards))
+	for _, validator := range rewards {
+		total := new(big.Int)
+		for _, reward := range validator.Reward {
+			if reward.Denom == chain.StakingDenom {
+				total.Add(total, cosmosDecToInt(reward.Amount))
+			}
+		}
+		unclaimed[validator.ValidatorAddress] = total
+	}
+
+	positions := make([]*models.CosmosDelegation, 0, len(delegations))
+	for _, delegation := range delegations {
+		validator := delegation.Delegation.ValidatorAddress
+		reward, ok := unclaimed[validator]
+		if !ok {
+			reward = new(big.Int)
+		}
+		delete(unclaimed, validator)
+		positions = append(positions, &models.CosmosDelegation{
+			Chain:            chain.Ref(),
+			ValidatorAddress: validator,
+			Token:            stakingToken,
+			Amount:           delegation.Balance.Amount,
+			Rewards:          reward.String(),
+		})
+	}
+	for _, validator := range rewards {
+		reward, ok := unclaimed[validator.ValidatorAddress]
+		if !ok || reward.Sign() == 0 {
+			continue
+		}
+		positions = append(positions, &models.CosmosDelegation{
+			Chain:            chain.Ref(),
+			ValidatorAddress: validator.ValidatorAddress,
+			Token:            stakingToken,
+			Amount:           "0",
+			Rewards:          reward.String(),
+		})
+	}
+	return positions
+}
+
+// cosmosDecToInt truncates an LCD decimal amount to whole smallest units
+func cosmosDecToInt(amount string) *big.Int {
+	whole, _, _ := strings.Cut(amount, ".")
+	value, ok := new(big.Int).SetString(whole, 10)
+	if !ok {
+		return new(big.Int)
+	}
+	return value
+}
+
+// cosmosTokenResolver builds token models for denoms, following IBC vouchers back to their
+// base denom
+type cosmosTokenResolver struct {
+	client *external.CosmosClient
+	chain  CosmosChain
+	tokens map[string]*models.Token
+}
+
+func (r *cosmosTokenResolver) token(ctx context.Context, denom string) *models.Token {
+	if token, ok := r.tokens[denom]; ok {
+		return token
+	}
+
+	base := denom
+	if hash, ok := strings.CutPrefix(denom, "ibc/"); ok {
+		trace, err := r.client.GetDenomTrace(ctx, hash)
+		if err != nil {
+			logger.Warn("Failed to resolve IBC denom", "error", err, "denom", denom, "chain", r.chain.ChainID)
+		} else {
+			base = trace.BaseDenom
+		}
+	}
+
+	ref := r.chain.Ref()
+	token := &models.Token{
+		ID:      uuid.New(),
+		Address: denom,
+		Chain:   &ref,
+		Symbol:  denom,
+		Name:    denom,
+	}
+	if asset, ok := cosmosAssets[base]; ok {
+		token.Symbol = asset.symbol
+		token.Name = asset.name
+		token.Decimals = asset.decimals
+	}
+
+	r.tokens[denom] = token
+	return token
+}
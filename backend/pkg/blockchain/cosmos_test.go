@@ -0,0 +1,99 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/external"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBech32(t *testing.T) {
+	// BIP-173 test vectors
+	hrp, payload, err := DecodeBech32("A12UEL5L")
+	require.NoError(t, err)
+	assert.Equal(t, "a", hrp)
+	assert.Empty(t, payload)
+
+	hrp, payload, err = DecodeBech32("abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", hrp)
+	assert.Len(t, payload, 20)
+
+	_, _, err = DecodeBech32("a12uel5m")
+	assert.Error(t, err, "bad checksum")
+	_, _, err = DecodeBech32("A12uEL5L")
+	assert.Error(t, err, "mixed case")
+	_, _, err = DecodeBech32("0x1234567890123456789012345678901234567890")
+	assert.Error(t, err)
+}
+
+func TestCosmosAddresses(t *testing.T) {
+	payload := []byte{
+		0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23,
+		0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67,
+	}
+	hub, err := EncodeBech32("cosmos", payload)
+	require.NoError(t, err)
+
+	chain, ok := CosmosChainForAddress(hub)
+	require.True(t, ok)
+	assert.Equal(t, ChainIDCosmosHub, chain.ChainID)
+	assert.Equal(t, "cosmos:cosmoshub-4", chain.Ref().String())
+
+	osmo, err := CosmosAddressOn(hub, cosmosChains[1])
+	require.NoError(t, err)
+	chain, ok = CosmosChainForAddress(osmo)
+	require.True(t, ok)
+	assert.Equal(t, ChainIDOsmosis, chain.ChainID)
+
+	_, decoded, err := DecodeBech32(osmo)
+	require.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+
+	assert.False(t, IsCosmosAddress(mustEncodeBech32(t, "juno", payload)), "unsupported chain")
+	assert.False(t, IsCosmosAddress("0x1234567890123456789012345678901234567890"))
+}
+
+func TestCosmosDelegations(t *testing.T) {
+	chain := cosmosChains[0]
+	token := &models.Token{Symbol: "ATOM", Decimals: 6}
+
+	var delegation external.CosmosDelegation
+	delegation.Delegation.ValidatorAddress = "cosmosvaloper1a"
+	delegation.Balance = external.CosmosCoin{Denom: "uatom", Amount: "5000000"}
+
+	rewards := []external.CosmosValidatorRewards{
+		{ValidatorAddress: "cosmosvaloper1a", Reward: []external.CosmosCoin{
+			{Denom: "uatom", Amount: "1234.999000000000000000"},
+			{Denom: "ibc/ABC", Amount: "99.000000000000000000"},
+		}},
+		// Undelegated, rewards not yet claimed
+		{ValidatorAddress: "cosmosvaloper1b", Reward: []external.CosmosCoin{
+			{Denom: "uatom", Amount: "10.500000000000000000"},
+		}},
+		{ValidatorAddress: "cosmosvaloper1c", Reward: []external.CosmosCoin{
+			{Denom: "uatom", Amount: "0.400000000000000000"},
+		}},
+	}
+
+	positions := cosmosDelegations(chain, token, []external.CosmosDelegation{delegation}, rewards)
+	require.Len(t, positions, 2)
+
+	assert.Equal(t, "cosmosvaloper1a", positions[0].ValidatorAddress)
+	assert.Equal(t, "5000000", positions[0].Amount)
+	assert.Equal(t, "1234", positions[0].Rewards)
+	assert.Equal(t, chain.Ref(), positions[0].Chain)
+
+	assert.Equal(t, "cosmosvaloper1b", positions[1].ValidatorAddress)
+	assert.Equal(t, "0", positions[1].Amount)
+	assert.Equal(t, "10", positions[1].Rewards)
+}
+
+func mustEncodeBech32(t *testing.T, hrp string, payload []byte) string {
+	t.Helper()
+	address, err := EncodeBech32(hrp, payload)
+	require.NoError(t, err)
+	return address
+}
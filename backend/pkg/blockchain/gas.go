@@ -0,0 +1,147 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// nativeTokenCoinGeckoIDs maps chain IDs to the CoinGecko ID of the gas token
+var nativeTokenCoinGeckoIDs = map[int]string{
+	ChainIDEthereum:    "ethereum",
+	ChainIDPolygon:     "matic-network",
+	ChainIDArbitrum:    "ethereum",
+	ChainIDOptimism:    "ethereum",
+	ChainIDPolygonAmoy: "matic-network",
+}
+
+// NativeTokenCoinGeckoID returns the CoinGecko ID of the chain's gas token
+func NativeTokenCoinGeckoID(chainID int) string {
+	if id, ok := nativeTokenCoinGeckoIDs[chainID]; ok {
+		return id
+	}
+	if chain, ok := LookupCustomChain(chainID); ok && chain.NativeCoinGeckoID != nil {
+		return *chain.NativeCoinGeckoID
+	}
+	return "ethereum"
+}
+
+// l1FeeChains are the rollups whose receipts add an L1 data fee to what gas costs, so
+// their transactions are priced from the receipt even when gas usage is known
+var l1FeeChains = map[int]bool{
+	ChainIDArbitrum: true,
+	ChainIDOptimism: true,
+}
+
+// GasFeeNative returns gasUsed * gasPrice expressed in whole native tokens (18 decimals)
+func GasFeeNative(gasUsed int64, gasPrice string) (float64, error) {
+	price, ok := new(big.Int).SetString(gasPrice, 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid gas price: %s", gasPrice)
+	}
+
+	feeWei := new(big.Int).Mul(big.NewInt(gasUsed), price)
+	return ParseTokenAmount(feeWei.String(), 18)
+}
+
+// totalFeeNative returns the gas fee plus the receipt's L1 data fee, if any, in whole
+// native tokens
+func totalFeeNative(gasUsed int64, gasPrice, l1Fee string) (float64, error) {
+	fee, err := GasFeeNative(gasUsed, gasPrice)
+	if err != nil || l1Fee == "" {
+		return fee, err
+	}
+	l1FeeNative, err := ParseTokenAmount(l1Fee, 18)
+	if err != nil {
+		return 0, fmt.Errorf("invalid l1 fee: %s", l1Fee)
+	}
+	return fee + l1FeeNative, nil
+}
+
+// EnrichGasFees fills GasUsed, GasPrice and GasFeeUSD for transactions that lack them.
+// Receipts are fetched once per hash and native prices once per chain and day. On
+// rollups the fee includes the L1 data fee the receipt reports.
+func (s *BlockchainService) EnrichGasFees(ctx context.Context, transactions []*models.Transaction) {
+	receipts := make(map[string]*TransactionReceipt)
+	prices := make(map[string]float64)
+
+	for _, tx := range transactions {
+		if tx.GasFeeUSD != nil {
+			continue
+		}
+
+		var l1Fee string
+		if tx.GasUsed == nil || tx.GasPrice == nil || l1FeeChains[tx.ChainID] {
+			receipt, ok := receipts[tx.Hash]
+			if !ok {
+				var err error
+				receipt, err = s.alchemyClient.GetTransactionReceipt(ctx, tx.Hash, tx.ChainID)
+				if err != nil {
+					logger.Warn("Failed to fetch transaction receipt", "hash", tx.Hash, "error", err)
+					continue
+				}
+				receipts[tx.Hash] = receipt
+			}
+			gasUsed := receipt.GasUsed
+			gasPrice := receipt.EffectiveGasPrice
+			tx.GasUsed = &gasUsed
+			tx.GasPrice = &gasPrice
+			l1Fee = receipt.L1Fee
+		}
+
+		feeNative, err := totalFeeNative(*tx.GasUsed, *tx.GasPrice, l1Fee)
+		if err != nil {
+			logger.Warn("Failed to compute gas fee", "hash", tx.Hash, "error", err)
+			continue
+		}
+
+		cgID := NativeTokenCoinGeckoID(tx.ChainID)
+		priceKey := fmt.Sprintf("%s:%s", cgID, tx.Timestamp.UTC().Format(time.DateOnly))
+		price, ok := prices[priceKey]
+		if !ok {
+			price, err = s.coinGeckoClient.GetHistoricalPrice(ctx, cgID, tx.Timestamp)
+			if err != nil {
+				logger.Warn("Failed to fetch historical native price", "token", cgID, "error", err)
+				continue
+			}
+			prices[priceKey] = price
+		}
+
+		feeUSD := feeNative * price
+		tx.GasFeeUSD = &feeUSD
+	}
+}
+
+// GetGasPriceGwei returns the chain's current gas price in gwei
+func (s *BlockchainService) GetGasPriceGwei(ctx context.Context, chainID int) (float64, error) {
+	wei, err := s.alchemyClient.GetGasPrice(ctx, chainID)
+	if err != nil {
+		return 0, err
+	}
+
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return gwei, nil
+}
+
+// GetNativeBalance returns an address's balance of the chain's gas token, in wei
+func (s *BlockchainService) GetNativeBalance(ctx context.Context, chainID int, address string) (*big.Int, error) {
+	return s.alchemyClient.GetETHBalance(ctx, address, chainID)
+}
+
+// GetNativePriceUSD returns the current USD price of the chain's gas token
+func (s *BlockchainService) GetNativePriceUSD(ctx context.Context, chainID int) (float64, error) {
+	id := NativeTokenCoinGeckoID(chainID)
+	prices, err := s.coinGeckoClient.GetTokenPrices(ctx, []string{id})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s price: %w", id, err)
+	}
+	price, ok := prices[id]
+	if !ok {
+		return 0, fmt.Errorf("no %s price returned", id)
+	}
+	return price.USD, nil
+}
@@ -0,0 +1,72 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasFeeNative(t *testing.T) {
+	// 21000 gas at 20 gwei = 0.00042 ETH
+	fee, err := GasFeeNative(21000, "20000000000")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.00042, fee, 1e-12)
+
+	_, err = GasFeeNative(21000, "not-a-number")
+	assert.Error(t, err)
+}
+
+func TestNativeTokenCoinGeckoID(t *testing.T) {
+	assert.Equal(t, "ethereum", NativeTokenCoinGeckoID(ChainIDArbitrum))
+	assert.Equal(t, "matic-network", NativeTokenCoinGeckoID(ChainIDPolygon))
+	assert.Equal(t, "ethereum", NativeTokenCoinGeckoID(999999))
+}
+
+func TestTotalFeeNative(t *testing.T) {
+	// 21000 gas at 0.001 gwei plus a 0.00005 ETH L1 data fee
+	fee, err := totalFeeNative(21000, "1000000", "50000000000000")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.000000021+0.00005, fee, 1e-15)
+
+	fee, err = totalFeeNative(21000, "20000000000", "")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.00042, fee, 1e-12)
+}
+
+func TestGetTransactionReceiptL1Fee(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receipt := map[string]string{
+			"gasUsed":           "0x5208",
+			"effectiveGasPrice": "0xf4240",
+			"status":            "0x1",
+			"blockNumber":       "0x64",
+			"blockHash":         "0xABC",
+		}
+		// Only the rollup's node reports an L1 data fee
+		if r.URL.Path == "/optimism" {
+			receipt["l1Fee"] = "0x2d79883d2000"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": receipt})
+	}))
+	defer server.Close()
+	client := &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{
+		ChainIDEthereum: server.URL + "/ethereum",
+		ChainIDOptimism: server.URL + "/optimism",
+	}}
+	ctx := context.Background()
+
+	receipt, err := client.GetTransactionReceipt(ctx, "0x1", ChainIDOptimism)
+	require.NoError(t, err)
+	assert.Equal(t, int64(21000), receipt.GasUsed)
+	assert.Equal(t, "1000000", receipt.EffectiveGasPrice)
+	assert.Equal(t, "50000000000000", receipt.L1Fee)
+
+	receipt, err = client.GetTransactionReceipt(ctx, "0x1", ChainIDEthereum)
+	require.NoError(t, err)
+	assert.Empty(t, receipt.L1Fee)
+}
@@ -0,0 +1,105 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// gmxV2DataStore holds GMX v2 position state on Arbitrum
+	gmxV2DataStore = "0xFD70de6b91282D8017aA4E741e9Ae325CAb992d8"
+	// gmxUSDDecimals is the precision of GMX's USD amounts
+	gmxUSDDecimals = 30
+	// gmxPositionPageSize is how many positions one getAccountPositions call reads
+	gmxPositionPageSize = 100
+)
+
+// gmxV2Reader is the GMX v2 Reader on Arbitrum. GMX redeploys the Reader on upgrades;
+// the DataStore it reads from stays put.
+var gmxV2Reader = "0x5Ca84c34a381434786738735265b9f3FD814b824"
+
+var selGetAccountPositions = methodID("getAccountPositions(address,address,uint256,uint256)")
+
+// GMXPosition is a raw GMX v2 position as stored on-chain
+type GMXPosition struct {
+	Market          string
+	CollateralToken string
+	// SizeInUSD has 30 decimals; SizeInTokens and CollateralAmount are in the smallest
+	// unit of the index and collateral tokens
+	SizeInUSD        *big.Int
+	SizeInTokens     *big.Int
+	CollateralAmount *big.Int
+	IsLong           bool
+}
+
+// SizeUSD is the position's notional size at open
+func (p *GMXPosition) SizeUSD() float64 {
+	return scaleDown(p.SizeInUSD, gmxUSDDecimals)
+}
+
+// Size is the position size in whole index tokens
+func (p *GMXPosition) Size(indexDecimals int) float64 {
+	return scaleDown(p.SizeInTokens, indexDecimals)
+}
+
+// Collateral is the collateral in whole collateral tokens
+func (p *GMXPosition) Collateral(collateralDecimals int) float64 {
+	return scaleDown(p.CollateralAmount, collateralDecimals)
+}
+
+// GetGMXPositions reads a wallet's open GMX v2 positions on Arbitrum
+func (s *BlockchainService) GetGMXPositions(ctx context.Context, address string) ([]*GMXPosition, error) {
+	var positions []*GMXPosition
+	for start := int64(0); ; start += gmxPositionPageSize {
+		out, err := s.alchemyClient.ethCall(ctx, ChainIDArbitrum, gmxV2Reader, selGetAccountPositions,
+			encodeAddress(gmxV2DataStore),
+			encodeAddress(address),
+			encodeUint(big.NewInt(start)),
+			encodeUint(big.NewInt(start+gmxPositionPageSize)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GMX positions: %w", err)
+		}
+
+		page, err := decodeGMXPositions(out)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, page...)
+		if len(page) < gmxPositionPageSize {
+			return positions, nil
+		}
+	}
+}
+
+// decodeGMXPositions decodes a Position.Props[] return value. Props is a static struct
+// (addresses, numbers, flags), so entries are laid out inline after the array length.
+// Its numbers grow between GMX releases, so the entry width is inferred from the data:
+// the three addresses and the first three numbers lead, and isLong is the last word.
+func decodeGMXPositions(data []byte) ([]*GMXPosition, error) {
+	count := int(decodeUint(data, 1).Int64())
+	if count == 0 {
+		return nil, nil
+	}
+
+	words := len(data)/32 - 2
+	if words%count != 0 || words/count < 7 {
+		return nil, fmt.Errorf("unexpected GMX positions encoding: %d words for %d positions", words, count)
+	}
+	width := words / count
+
+	positions := make([]*GMXPosition, count)
+	for i := range positions {
+		base := 2 + i*width
+		positions[i] = &GMXPosition{
+			Market:           decodeAddress(data, base+1).Hex(),
+			CollateralToken:  decodeAddress(data, base+2).Hex(),
+			SizeInUSD:        decodeUint(data, base+3),
+			SizeInTokens:     decodeUint(data, base+4),
+			CollateralAmount: decodeUint(data, base+5),
+			IsLong:           decodeUint(data, base+width-1).Sign() != 0,
+		}
+	}
+	return positions, nil
+}
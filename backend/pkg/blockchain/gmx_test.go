@@ -0,0 +1,69 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gmxPositionWords(market, collateral string, sizeUSD, sizeTokens, collateralAmount *big.Int, isLong bool, width int) [][]byte {
+	words := [][]byte{
+		encodeAddress("0x1111111111111111111111111111111111111111"),
+		encodeAddress(market),
+		encodeAddress(collateral),
+		encodeUint(sizeUSD),
+		encodeUint(sizeTokens),
+		encodeUint(collateralAmount),
+	}
+	for len(words) < width-1 {
+		words = append(words, encodeUint(big.NewInt(7)))
+	}
+	flag := big.NewInt(0)
+	if isLong {
+		flag = big.NewInt(1)
+	}
+	return append(words, encodeUint(flag))
+}
+
+func TestDecodeGMXPositions(t *testing.T) {
+	market := "0x70d95587d40A2caf56bd97485aB3Eec10Bee6336"
+	usdc := "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	tenThousandUSD := new(big.Int).Mul(big.NewInt(10_000), new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil))
+
+	for _, width := range []int{14, 15} {
+		data := append([]byte{}, encodeUint(big.NewInt(32))...)
+		data = append(data, encodeUint(big.NewInt(2))...)
+		for _, word := range gmxPositionWords(market, usdc, tenThousandUSD, big.NewInt(4e18), big.NewInt(2_000e6), true, width) {
+			data = append(data, word...)
+		}
+		for _, word := range gmxPositionWords(market, usdc, tenThousandUSD, big.NewInt(4e18), big.NewInt(2_000e6), false, width) {
+			data = append(data, word...)
+		}
+
+		positions, err := decodeGMXPositions(data)
+		require.NoError(t, err)
+		require.Len(t, positions, 2)
+		assert.Equal(t, common.HexToAddress(market).Hex(), positions[0].Market)
+		assert.Equal(t, common.HexToAddress(usdc).Hex(), positions[0].CollateralToken)
+		assert.InDelta(t, 10_000, positions[0].SizeUSD(), 1e-6)
+		assert.Equal(t, "4000000000000000000", positions[0].SizeInTokens.String())
+		assert.Equal(t, int64(2_000e6), positions[0].CollateralAmount.Int64())
+		assert.True(t, positions[0].IsLong)
+		assert.False(t, positions[1].IsLong)
+	}
+}
+
+func TestDecodeGMXPositionsEmptyAndMalformed(t *testing.T) {
+	empty := append(encodeUint(big.NewInt(32)), encodeUint(big.NewInt(0))...)
+	positions, err := decodeGMXPositions(empty)
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+
+	malformed := append(encodeUint(big.NewInt(32)), encodeUint(big.NewInt(2))...)
+	malformed = append(malformed, make([]byte, 32*9)...)
+	_, err = decodeGMXPositions(malformed)
+	assert.Error(t, err)
+}
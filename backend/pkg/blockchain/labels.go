@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// Wallet labels suggested from a wallet's on-chain activity
+const (
+	LabelUniswapLP     = "Uniswap LP"
+	LabelUniswapTrader = "Uniswap trader"
+	LabelAaveBorrower  = "Aave borrower"
+	LabelAaveUser      = "Aave user"
+	LabelCompoundUser  = "Compound user"
+	LabelNFTTrader     = "NFT trader"
+	LabelLidoStaker    = "Lido staker"
+)
+
+// CountLabelInteractions counts, per label, the transactions of the wallet at address
+// that suggest it: dealing with a known protocol contract, receiving Aave debt tokens or
+// Uniswap v2 LP tokens. A transaction counts once per label however many of its
+// transfers match.
+func CountLabelInteractions(address string, transactions []*models.Transaction) map[string]int {
+	seen := make(map[string]bool)
+	counts := make(map[string]int)
+	count := func(label, hash string) {
+		if key := label + "|" + hash; !seen[key] {
+			seen[key] = true
+			counts[label]++
+		}
+	}
+
+	for _, tx := range transactions {
+		received := tx.ToAddress != nil && strings.EqualFold(*tx.ToAddress, address)
+		counterparty := tx.FromAddress
+		if !received && tx.ToAddress != nil {
+			counterparty = *tx.ToAddress
+		}
+		if contract, ok := knownContracts[strings.ToLower(counterparty)]; ok {
+			count(contract.label, tx.Hash)
+		}
+
+		asset, _ := tx.Metadata["asset"].(string)
+		switch {
+		case !received:
+		case strings.HasPrefix(asset, "variableDebt"), strings.HasPrefix(asset, "stableDebt"):
+			count(LabelAaveBorrower, tx.Hash)
+		case asset == "UNI-V2":
+			count(LabelUniswapLP, tx.Hash)
+		}
+	}
+	return counts
+}
@@ -0,0 +1,32 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountLabelInteractions(t *testing.T) {
+	wallet := "0x8ba1f109551bd432803012645ac136ddd64dba72"
+	to := func(address string) *string { return &address }
+	transactions := []*models.Transaction{
+		// A liquidity add sends both tokens to the position manager in one transaction
+		{Hash: "0x01", FromAddress: wallet, ToAddress: to("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"), Metadata: map[string]interface{}{"asset": "USDC"}},
+		{Hash: "0x01", FromAddress: wallet, ToAddress: to("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"), Metadata: map[string]interface{}{"asset": "WETH"}},
+		{Hash: "0x02", FromAddress: "0x0000000000000000000000000000000000000000", ToAddress: to(wallet), Metadata: map[string]interface{}{"asset": "UNI-V2"}},
+		{Hash: "0x03", FromAddress: "0x0000000000000000000000000000000000000000", ToAddress: to(wallet), Metadata: map[string]interface{}{"asset": "variableDebtEthUSDC"}},
+		{Hash: "0x04", FromAddress: wallet, ToAddress: to("0x87870Bca3F3fD6335C3F4ce8392D69350B4fA4E2"), Metadata: map[string]interface{}{"asset": "USDC"}},
+		{Hash: "0x05", FromAddress: "0x00000000000000ADc04C56Bf30aC9d3c0aAF14dC", ToAddress: to(wallet), Metadata: map[string]interface{}{"asset": "ETH"}},
+		// Sending debt tokens on isn't borrowing
+		{Hash: "0x06", FromAddress: wallet, ToAddress: to("0x1111111111111111111111111111111111111111"), Metadata: map[string]interface{}{"asset": "variableDebtEthUSDC"}},
+	}
+
+	assert.Equal(t, map[string]int{
+		LabelUniswapLP:    2,
+		LabelAaveBorrower: 1,
+		LabelAaveUser:     1,
+		LabelNFTTrader:    1,
+	}, CountLabelInteractions(wallet, transactions))
+	assert.Empty(t, CountLabelInteractions(wallet, nil))
+}
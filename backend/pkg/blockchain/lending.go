@@ -0,0 +1,325 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// aaveV3Pools are the Aave v3 Pool deployments per chain
+var aaveV3Pools = map[int]string{
+	ChainIDEthereum: "0x87870Bca3F3fD6335C3F4ce8392D69350B4fA4E2",
+	ChainIDPolygon:  "0x794a61358D6845594F94dc1DB02A252b5b4814aD",
+	ChainIDArbitrum: "0x794a61358D6845594F94dc1DB02A252b5b4814aD",
+	ChainIDOptimism: "0x794a61358D6845594F94dc1DB02A252b5b4814aD",
+}
+
+// compoundV3Markets are the Compound v3 Comet deployments per chain
+var compoundV3Markets = map[int][]string{
+	ChainIDEthereum: {
+		"0xc3d688B66703497DAA19211EEdff47f25384cdc3", // cUSDCv3
+		"0xA17581A9E3356d9A858b789D68B4d866e593aE94", // cWETHv3
+	},
+	ChainIDPolygon:  {"0xF25212E676D1F7F89Cd72fFEe66158f541246445"}, // cUSDCv3
+	ChainIDArbitrum: {"0x9c4ec768c28520B50860ea7a15bd7213a9fF58bf"}, // cUSDCv3
+	ChainIDOptimism: {"0x2e44e174f7D53F0212823acC11C01A11d58c5bCB"}, // cUSDCv3
+}
+
+// cometUSDFeeds are the Chainlink USD feeds, by Comet, of markets whose getPrice isn't
+// denominated in USD; Comets missing here price in USD
+var cometUSDFeeds = map[string]string{
+	"0xA17581A9E3356d9A858b789D68B4d866e593aE94": "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419", // cWETHv3 prices in ETH: ETH/USD
+}
+
+var (
+	selGetUserAccountData  = methodID("getUserAccountData(address)")
+	selBorrowBalanceOf     = methodID("borrowBalanceOf(address)")
+	selBaseToken           = methodID("baseToken()")
+	selBaseScale           = methodID("baseScale()")
+	selBaseTokenPriceFeed  = methodID("baseTokenPriceFeed()")
+	selGetPrice            = methodID("getPrice(address)")
+	selNumAssets           = methodID("numAssets()")
+	selGetAssetInfo        = methodID("getAssetInfo(uint8)")
+	selCollateralBalanceOf = methodID("collateralBalanceOf(address,address)")
+	selLatestRoundData     = methodID("latestRoundData()")
+)
+
+const (
+	// aaveBaseDecimals is the precision of Aave v3's USD base currency
+	aaveBaseDecimals = 8
+	// cometPriceDecimals is the precision of Comet's getPrice
+	cometPriceDecimals = 8
+	// chainlinkUSDDecimals is the precision of Chainlink's USD feeds
+	chainlinkUSDDecimals = 8
+	// wadDecimals is the 1e18 precision of health factors and collateral factors
+	wadDecimals = 18
+)
+
+// SupportsDebtPositions reports whether any lending market is configured for the chain
+func SupportsDebtPositions(chainID int) bool {
+	_, aave := aaveV3Pools[chainID]
+	_, compound := compoundV3Markets[chainID]
+	return aave || compound
+}
+
+// GetDebtPositions reads a wallet's open borrow positions on Aave v3 and Compound v3.
+// Markets where the wallet has no debt are omitted.
+func (s *BlockchainService) GetDebtPositions(ctx context.Context, address string, chainID int) ([]*models.DebtPosition, error) {
+	if !SupportsDebtPositions(chainID) {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		positions []*models.DebtPosition
+		firstErr  error
+	)
+	collect := func(position *models.DebtPosition, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return
+		}
+		if position != nil {
+			positions = append(positions, position)
+		}
+	}
+
+	if pool, ok := aaveV3Pools[chainID]; ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collect(s.alchemyClient.aaveV3Position(ctx, chainID, pool, address))
+		}()
+	}
+	for _, comet := range compoundV3Markets[chainID] {
+		wg.Add(1)
+		go func(comet string) {
+			defer wg.Done()
+			collect(s.alchemyClient.compoundV3Position(ctx, chainID, comet, address))
+		}(comet)
+	}
+	wg.Wait()
+
+	// A partial read is more useful than none; fail only when every market failed
+	if firstErr != nil {
+		if len(positions) == 0 {
+			return nil, firstErr
+		}
+		logger.Warn("Some lending markets could not be read", "address", address, "chainID", chainID, "error", firstErr)
+	}
+	return positions, nil
+}
+
+func (c *AlchemyClient) aaveV3Position(ctx context.Context, chainID int, pool, address string) (*models.DebtPosition, error) {
+	out, err := c.ethCall(ctx, chainID, pool, selGetUserAccountData, encodeAddress(address))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Aave account data: %w", err)
+	}
+
+	debt := scaleDown(decodeUint(out, 1), aaveBaseDecimals)
+	if debt == 0 {
+		return nil, nil
+	}
+	collateral := scaleDown(decodeUint(out, 0), aaveBaseDecimals)
+	healthFactor := scaleDown(decodeUint(out, 5), wadDecimals)
+
+	return &models.DebtPosition{
+		Protocol:               models.DebtProtocolAaveV3,
+		ChainID:                chainID,
+		Market:                 common.HexToAddress(pool).Hex(),
+		WalletAddress:          address,
+		CollateralUSD:          collateral,
+		DebtUSD:                debt,
+		AvailableBorrowsUSD:    scaleDown(decodeUint(out, 2), aaveBaseDecimals),
+		LiquidationThreshold:   scaleDown(decodeUint(out, 3), 4), // basis points
+		LTV:                    scaleDown(decodeUint(out, 4), 4),
+		HealthFactor:           healthFactor,
+		LiquidationDropPercent: liquidationDropPercent(healthFactor),
+	}, nil
+}
+
+// cometCollateral is one collateral asset of a Compound v3 position
+type cometCollateral struct {
+	asset                string
+	priceUSD             float64
+	valueUSD             float64
+	borrowFactor         float64
+	liquidationThreshold float64
+}
+
+func (c *AlchemyClient) compoundV3Position(ctx context.Context, chainID int, comet, address string) (*models.DebtPosition, error) {
+	call := func(selector []byte, args ...[]byte) ([]byte, error) {
+		out, err := c.ethCall(ctx, chainID, comet, selector, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Compound market %s: %w", comet, err)
+		}
+		return out, nil
+	}
+
+	out, err := call(selBorrowBalanceOf, encodeAddress(address))
+	if err != nil {
+		return nil, err
+	}
+	borrowed := decodeUint(out, 0)
+	if borrowed.Sign() == 0 {
+		return nil, nil
+	}
+
+	out, err = call(selBaseToken)
+	if err != nil {
+		return nil, err
+	}
+	baseToken := decodeAddress(out, 0).Hex()
+	out, err = call(selBaseScale)
+	if err != nil {
+		return nil, err
+	}
+	baseScale := decodeUint(out, 0)
+	out, err = call(selBaseTokenPriceFeed)
+	if err != nil {
+		return nil, err
+	}
+	basePrice, err := call(selGetPrice, encodeAddress(decodeAddress(out, 0).Hex()))
+	if err != nil {
+		return nil, err
+	}
+	usdRate, err := c.cometUSDRate(ctx, chainID, comet)
+	if err != nil {
+		return nil, err
+	}
+	debtUSD := ratio(borrowed, baseScale) * scaleDown(decodeUint(basePrice, 0), cometPriceDecimals) * usdRate
+
+	out, err = call(selNumAssets)
+	if err != nil {
+		return nil, err
+	}
+	numAssets := decodeUint(out, 0).Int64()
+
+	var collaterals []cometCollateral
+	for i := int64(0); i < numAssets; i++ {
+		// AssetInfo: offset, asset, priceFeed, scale, borrowCollateralFactor,
+		// liquidateCollateralFactor, liquidationFactor, supplyCap
+		info, err := call(selGetAssetInfo, encodeUint(big.NewInt(i)))
+		if err != nil {
+			return nil, err
+		}
+		asset := decodeAddress(info, 1).Hex()
+
+		out, err := call(selCollateralBalanceOf, encodeAddress(address), encodeAddress(asset))
+		if err != nil {
+			return nil, err
+		}
+		balance := decodeUint(out, 0)
+		if balance.Sign() == 0 {
+			continue
+		}
+
+		out, err = call(selGetPrice, encodeAddress(decodeAddress(info, 2).Hex()))
+		if err != nil {
+			return nil, err
+		}
+		price := scaleDown(decodeUint(out, 0), cometPriceDecimals) * usdRate
+		collaterals = append(collaterals, cometCollateral{
+			asset:                asset,
+			priceUSD:             price,
+			valueUSD:             ratio(balance, decodeUint(info, 3)) * price,
+			borrowFactor:         scaleDown(decodeUint(info, 4), wadDecimals),
+			liquidationThreshold: scaleDown(decodeUint(info, 5), wadDecimals),
+		})
+	}
+
+	position := cometPosition(debtUSD, collaterals)
+	position.ChainID = chainID
+	position.Market = common.HexToAddress(comet).Hex()
+	position.WalletAddress = address
+	position.DebtAsset = &baseToken
+	return position, nil
+}
+
+// cometUSDRate is the USD value of one unit of the Comet's getPrice denomination
+func (c *AlchemyClient) cometUSDRate(ctx context.Context, chainID int, comet string) (float64, error) {
+	feed, ok := cometUSDFeeds[common.HexToAddress(comet).Hex()]
+	if !ok {
+		return 1, nil
+	}
+	// latestRoundData: roundId, answer, startedAt, updatedAt, answeredInRound
+	out, err := c.ethCall(ctx, chainID, feed, selLatestRoundData)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read USD price feed %s: %w", feed, err)
+	}
+	answer := decodeUint(out, 1)
+	if answer.Sign() == 0 || answer.Bit(255) == 1 {
+		return 0, fmt.Errorf("USD price feed %s returned no price", feed)
+	}
+	return scaleDown(answer, chainlinkUSDDecimals), nil
+}
+
+// cometPosition aggregates Compound v3 collateral into account-level figures. Comet has
+// no on-chain health factor, so it is derived as liquidation capacity over debt.
+func cometPosition(debtUSD float64, collaterals []cometCollateral) *models.DebtPosition {
+	position := &models.DebtPosition{
+		Protocol: models.DebtProtocolCompoundV3,
+		DebtUSD:  debtUSD,
+	}
+
+	var borrowCapacity, liquidationCapacity float64
+	for _, collateral := range collaterals {
+		position.CollateralUSD += collateral.valueUSD
+		borrowCapacity += collateral.valueUSD * collateral.borrowFactor
+		liquidationCapacity += collateral.valueUSD * collateral.liquidationThreshold
+	}
+
+	if position.CollateralUSD > 0 {
+		position.LTV = borrowCapacity / position.CollateralUSD
+		position.LiquidationThreshold = liquidationCapacity / position.CollateralUSD
+	}
+	if borrowCapacity > debtUSD {
+		position.AvailableBorrowsUSD = borrowCapacity - debtUSD
+	}
+	if debtUSD > 0 {
+		position.HealthFactor = liquidationCapacity / debtUSD
+	}
+	position.LiquidationDropPercent = liquidationDropPercent(position.HealthFactor)
+
+	// With one collateral asset, liquidation happens when its price falls by the same ratio
+	if len(collaterals) == 1 && position.HealthFactor > 0 {
+		asset := collaterals[0].asset
+		price := collaterals[0].priceUSD / position.HealthFactor
+		position.CollateralAsset = &asset
+		position.LiquidationPrice = &price
+	}
+
+	return position
+}
+
+// liquidationDropPercent is the fall in collateral value that brings the health factor to 1
+func liquidationDropPercent(healthFactor float64) float64 {
+	if healthFactor <= 1 {
+		return 0
+	}
+	return (1 - 1/healthFactor) * 100
+}
+
+// scaleDown converts a fixed-point integer with the given decimals to a float
+func scaleDown(v *big.Int, decimals int) float64 {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return ratio(v, scale)
+}
+
+func ratio(numerator, denominator *big.Int) float64 {
+	if denominator.Sign() == 0 {
+		return 0
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(numerator), new(big.Float).SetInt(denominator)).Float64()
+	return f
+}
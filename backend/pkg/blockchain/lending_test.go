@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCometPosition(t *testing.T) {
+	// 10 ETH at $2000 with an 83% borrow and 90% liquidation factor, $12,000 borrowed
+	position := cometPosition(12000, []cometCollateral{{
+		asset:                "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		priceUSD:             2000,
+		valueUSD:             20000,
+		borrowFactor:         0.83,
+		liquidationThreshold: 0.9,
+	}})
+
+	assert.Equal(t, 20000.0, position.CollateralUSD)
+	assert.InDelta(t, 1.5, position.HealthFactor, 1e-9)
+	assert.InDelta(t, 4600.0, position.AvailableBorrowsUSD, 1e-9)
+	assert.InDelta(t, 0.9, position.LiquidationThreshold, 1e-9)
+	assert.InDelta(t, 33.333, position.LiquidationDropPercent, 1e-3)
+	require.NotNil(t, position.LiquidationPrice)
+	assert.InDelta(t, 1333.333, *position.LiquidationPrice, 1e-3)
+
+	// Liquidation price is ambiguous with several collateral assets
+	multi := cometPosition(1000, []cometCollateral{
+		{asset: "0x1", priceUSD: 1, valueUSD: 1000, borrowFactor: 0.8, liquidationThreshold: 0.85},
+		{asset: "0x2", priceUSD: 1, valueUSD: 1000, borrowFactor: 0.7, liquidationThreshold: 0.75},
+	})
+	assert.InDelta(t, 1.6, multi.HealthFactor, 1e-9)
+	assert.Nil(t, multi.LiquidationPrice)
+}
+
+func TestLiquidationDropPercent(t *testing.T) {
+	assert.InDelta(t, 50.0, liquidationDropPercent(2), 1e-9)
+	assert.Equal(t, 0.0, liquidationDropPercent(0.95))
+	assert.Equal(t, 0.0, liquidationDropPercent(0))
+}
+
+func TestScaleDown(t *testing.T) {
+	hf, _ := new(big.Int).SetString("1250000000000000000", 10)
+	assert.InDelta(t, 1.25, scaleDown(hf, wadDecimals), 1e-12)
+	assert.InDelta(t, 1234.5, scaleDown(big.NewInt(123450000000), aaveBaseDecimals), 1e-9)
+}
+
+func TestCompoundV3PositionConvertsETHPrices(t *testing.T) {
+	const (
+		comet  = "0xA17581A9E3356d9A858b789D68B4d866e593aE94" // cWETHv3
+		owner  = "0x1111111111111111111111111111111111111111"
+		weth   = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+		wsteth = "0x7f39C581F595B53c5cb19bD0b3f8dA6c935E2Ca0"
+		feed   = "0x2222222222222222222222222222222222222222"
+	)
+	ether, _ := new(big.Int).SetString("1000000000000000000", 10)
+	eth := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), ether) }
+	wad := func(percent int64) *big.Int {
+		return new(big.Int).Div(new(big.Int).Mul(ether, big.NewInt(percent)), big.NewInt(100))
+	}
+	word := func(v *big.Int) []byte { return encodeUint(v) }
+	addr := func(a string) []byte { return encodeAddress(a) }
+
+	// Comet prices are in ETH: WETH is 1, wstETH 1.2; ETH/USD is $2000
+	responses := map[string][]byte{
+		comet + hex.EncodeToString(selBorrowBalanceOf):                 word(eth(6)),
+		comet + hex.EncodeToString(selBaseToken):                       addr(weth),
+		comet + hex.EncodeToString(selBaseScale):                       word(ether),
+		comet + hex.EncodeToString(selBaseTokenPriceFeed):              addr("0x3333333333333333333333333333333333333333"),
+		comet + hex.EncodeToString(selNumAssets):                       word(big.NewInt(1)),
+		comet + hex.EncodeToString(selCollateralBalanceOf):             word(eth(10)),
+		feed + hex.EncodeToString(selLatestRoundData):                  bytes.Join([][]byte{word(big.NewInt(1)), word(big.NewInt(2000_00000000)), make([]byte, 96)}, nil),
+		comet + hex.EncodeToString(selGetAssetInfo):                    bytes.Join([][]byte{word(big.NewInt(0)), addr(wsteth), addr("0x4444444444444444444444444444444444444444"), word(ether), word(wad(80)), word(wad(90)), word(wad(95)), word(big.NewInt(0))}, nil),
+		comet + "price" + "0x3333333333333333333333333333333333333333": word(big.NewInt(1_00000000)),
+		comet + "price" + "0x4444444444444444444444444444444444444444": word(big.NewInt(1_20000000)),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var call struct {
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(req.Params[0], &call))
+		data := strings.TrimPrefix(call.Data, "0x")
+		key := common.HexToAddress(call.To).Hex() + data[:8]
+		if data[:8] == hex.EncodeToString(selGetPrice) {
+			key = common.HexToAddress(call.To).Hex() + "price" + common.HexToAddress(data[8:]).Hex()
+		}
+		out, ok := responses[key]
+		require.True(t, ok, "unexpected call %s", key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"jsonrpc": "2.0", "result": "0x" + hex.EncodeToString(out)})
+	}))
+	defer server.Close()
+
+	original := cometUSDFeeds[comet]
+	cometUSDFeeds[comet] = feed
+	defer func() { cometUSDFeeds[comet] = original }()
+
+	client := &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{ChainIDEthereum: server.URL}}
+	position, err := client.compoundV3Position(context.Background(), ChainIDEthereum, comet, owner)
+	require.NoError(t, err)
+
+	// 6 WETH of debt is $12,000; 10 wstETH at 1.2 ETH is $24,000
+	assert.InDelta(t, 12000.0, position.DebtUSD, 1e-6)
+	assert.InDelta(t, 24000.0, position.CollateralUSD, 1e-6)
+	assert.InDelta(t, 1.8, position.HealthFactor, 1e-9)
+	require.NotNil(t, position.LiquidationPrice)
+	assert.InDelta(t, 2400.0/1.8, *position.LiquidationPrice, 1e-6)
+}
+
+func TestCometUSDFeedsAreChecksummed(t *testing.T) {
+	// cometUSDRate looks Comets up by their checksummed address
+	for comet := range cometUSDFeeds {
+		assert.Equal(t, common.HexToAddress(comet).Hex(), comet)
+	}
+}
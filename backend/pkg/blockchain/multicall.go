@@ -0,0 +1,217 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Multicall3Address is the Multicall3 deployment, at the same address on every chain we support
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+// maxMulticallCalls bounds the calls aggregated into one eth_call, keeping it well under
+// node gas caps
+const maxMulticallCalls = 200
+
+var (
+	selAggregate3    = methodID("aggregate3((address,bool,bytes)[])")
+	selGetEthBalance = methodID("getEthBalance(address)")
+	selAllowance     = methodID("allowance(address,address)")
+)
+
+// Call is a read-only contract call batched through Multicall3
+type Call struct {
+	Target string
+	Data   []byte
+}
+
+// CallResult is the outcome of one batched call; a reverted call has Success unset
+type CallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// newCall builds a call of selector on target with ABI-encoded static arguments
+func newCall(target string, selector []byte, args ...[]byte) Call {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	return Call{Target: target, Data: data}
+}
+
+// multicall runs calls through Multicall3's aggregate3, at most maxMulticallCalls per
+// eth_call. Calls may fail individually without failing the batch.
+func (c *AlchemyClient) multicall(ctx context.Context, chainID int, calls []Call) ([]CallResult, error) {
+	return c.multicallAt(ctx, chainID, calls, nil)
+}
+
+// multicallAt is multicall against the state at the given block, or at the latest block if nil
+func (c *AlchemyClient) multicallAt(ctx context.Context, chainID int, calls []Call, block *int64) ([]CallResult, error) {
+	results := make([]CallResult, 0, len(calls))
+	for start := 0; start < len(calls); start += maxMulticallCalls {
+		end := start + maxMulticallCalls
+		if end > len(calls) {
+			end = len(calls)
+		}
+		data := append(append([]byte{}, selAggregate3...), encodeAggregate3(calls[start:end])...)
+		out, err := c.ethCallAt(ctx, chainID, "", Multicall3Address, data, block)
+		if err != nil {
+			return nil, fmt.Errorf("multicall failed: %w", err)
+		}
+		batch, err := decodeAggregate3(out, end-start)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+// encodeAggregate3 ABI-encodes the Call3[] argument of aggregate3, allowing every call
+// to fail
+func encodeAggregate3(calls []Call) []byte {
+	head := make([]byte, 0, 32*len(calls))
+	var tail []byte
+	for _, call := range calls {
+		head = append(head, encodeUint(big.NewInt(int64(32*len(calls)+len(tail))))...)
+		tail = append(tail, encodeAddress(call.Target)...)
+		tail = append(tail, encodeUint(big.NewInt(1))...)
+		tail = append(tail, encodeUint(big.NewInt(96))...)
+		tail = append(tail, encodeBytes(call.Data)...)
+	}
+
+	data := encodeUint(big.NewInt(32))
+	data = append(data, encodeUint(big.NewInt(int64(len(calls))))...)
+	data = append(data, head...)
+	return append(data, tail...)
+}
+
+// encodeBytes ABI-encodes a dynamic bytes value: its length, then the bytes right-padded
+// to a whole word
+func encodeBytes(b []byte) []byte {
+	padded := make([]byte, (len(b)+31)/32*32)
+	copy(padded, b)
+	return append(encodeUint(big.NewInt(int64(len(b)))), padded...)
+}
+
+// decodeAggregate3 decodes the Result[] aggregate3 returns, expecting count results
+func decodeAggregate3(data []byte, count int) ([]CallResult, error) {
+	offset, err := wordAt(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := wordAt(data, offset)
+	if err != nil {
+		return nil, err
+	}
+	if n != count {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", n, count)
+	}
+
+	base := offset + 32
+	results := make([]CallResult, n)
+	for i := range results {
+		tupleOffset, err := wordAt(data, base+32*i)
+		if err != nil {
+			return nil, err
+		}
+		tuple := base + tupleOffset
+		success, err := wordAt(data, tuple)
+		if err != nil {
+			return nil, err
+		}
+		dataOffset, err := wordAt(data, tuple+32)
+		if err != nil {
+			return nil, err
+		}
+		length, err := wordAt(data, tuple+dataOffset)
+		if err != nil {
+			return nil, err
+		}
+		start := tuple + dataOffset + 32
+		if length > len(data)-start {
+			return nil, errors.New("multicall return data out of range")
+		}
+		results[i] = CallResult{Success: success == 1, ReturnData: data[start : start+length]}
+	}
+	return results, nil
+}
+
+// wordAt reads the 32-byte word at pos as a length or offset into data
+func wordAt(data []byte, pos int) (int, error) {
+	if pos < 0 || pos > len(data)-32 {
+		return 0, errors.New("multicall result truncated")
+	}
+	v := new(big.Int).SetBytes(data[pos : pos+32])
+	if !v.IsInt64() || v.Int64() > int64(len(data)) {
+		return 0, errors.New("multicall offset out of range")
+	}
+	return int(v.Int64()), nil
+}
+
+// uintResult returns the first word of a successful call, or nil
+func uintResult(result CallResult) *big.Int {
+	if !result.Success || len(result.ReturnData) < 32 {
+		return nil
+	}
+	return decodeUint(result.ReturnData, 0)
+}
+
+// erc20Balances reads the native balance and the balances of tokens held by owner in a
+// single batch. The native balance is keyed by the zero address; tokens whose balanceOf
+// reverts are left out.
+func (c *AlchemyClient) erc20Balances(ctx context.Context, chainID int, owner string, tokens []string) (map[string]*big.Int, error) {
+	return c.erc20BalancesAt(ctx, chainID, owner, tokens, nil)
+}
+
+// erc20BalancesAt is erc20Balances as of the given block, or of the latest block if nil
+func (c *AlchemyClient) erc20BalancesAt(ctx context.Context, chainID int, owner string, tokens []string, block *int64) (map[string]*big.Int, error) {
+	calls := []Call{newCall(Multicall3Address, selGetEthBalance, encodeAddress(owner))}
+	for _, token := range tokens {
+		calls = append(calls, newCall(token, selBalanceOf, encodeAddress(owner)))
+	}
+
+	results, err := c.multicallAt(ctx, chainID, calls, block)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]*big.Int)
+	if balance := uintResult(results[0]); balance != nil {
+		balances[NativeTokenAddress] = balance
+	}
+	for i, token := range tokens {
+		if balance := uintResult(results[i+1]); balance != nil {
+			balances[token] = balance
+		}
+	}
+	return balances, nil
+}
+
+// AllowanceQuery is an ERC-20 approval to read: how much of Token Spender may move
+type AllowanceQuery struct {
+	Token   string
+	Spender string
+}
+
+// GetAllowances reads the current allowances owner has granted, in one batch per
+// maxMulticallCalls queries. An allowance that can't be read is nil.
+func (s *BlockchainService) GetAllowances(ctx context.Context, chainID int, owner string, queries []AllowanceQuery) ([]*big.Int, error) {
+	calls := make([]Call, len(queries))
+	for i, query := range queries {
+		calls[i] = newCall(query.Token, selAllowance, encodeAddress(owner), encodeAddress(query.Spender))
+	}
+
+	results, err := s.alchemyClient.multicall(ctx, chainID, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	allowances := make([]*big.Int, len(results))
+	for i, result := range results {
+		allowances[i] = uintResult(result)
+	}
+	return allowances, nil
+}
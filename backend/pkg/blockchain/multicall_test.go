@@ -0,0 +1,140 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const multicall3ABI = `[{"name":"aggregate3","type":"function","stateMutability":"payable",
+	"inputs":[{"name":"calls","type":"tuple[]","components":[
+		{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+	"outputs":[{"name":"returnData","type":"tuple[]","components":[
+		{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`
+
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+func TestEncodeAggregate3(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	require.NoError(t, err)
+
+	owner := "0x1111111111111111111111111111111111111111"
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	calls := []Call{
+		newCall(Multicall3Address, selGetEthBalance, encodeAddress(owner)),
+		newCall(usdc, selAllowance, encodeAddress(owner), encodeAddress(usdc)),
+		{Target: usdc, Data: []byte{0x01}},
+	}
+
+	var expected []call3
+	for _, call := range calls {
+		expected = append(expected, call3{Target: common.HexToAddress(call.Target), AllowFailure: true, CallData: call.Data})
+	}
+	packed, err := parsed.Pack("aggregate3", expected)
+	require.NoError(t, err)
+
+	assert.Equal(t, hex.EncodeToString(packed[:4]), hex.EncodeToString(selAggregate3))
+	assert.Equal(t, hex.EncodeToString(packed[4:]), hex.EncodeToString(encodeAggregate3(calls)))
+}
+
+func TestDecodeAggregate3(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	require.NoError(t, err)
+
+	balance := encodeUint(big.NewInt(1_500_000))
+	packed, err := parsed.Methods["aggregate3"].Outputs.Pack([]result3{
+		{Success: true, ReturnData: balance},
+		{Success: false, ReturnData: []byte("reverted: not a token")},
+		{Success: true, ReturnData: nil},
+	})
+	require.NoError(t, err)
+
+	results, err := decodeAggregate3(packed, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1_500_000), uintResult(results[0]).Int64())
+	assert.False(t, results[1].Success)
+	assert.Equal(t, "reverted: not a token", string(results[1].ReturnData))
+	assert.Nil(t, uintResult(results[1]))
+	assert.Nil(t, uintResult(results[2]), "empty return data")
+
+	_, err = decodeAggregate3(packed, 2)
+	assert.Error(t, err, "result count mismatch")
+	_, err = decodeAggregate3(packed[:len(packed)-40], 3)
+	assert.Error(t, err, "truncated")
+}
+
+func TestUniswapQuotesFromState(t *testing.T) {
+	weth := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	pair := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+
+	// USDC is token0: 2M USDC against 1k WETH
+	reserves := CallResult{Success: true, ReturnData: append(encodeUint(big.NewInt(2_000_000)), encodeUint(big.NewInt(1_000))...)}
+	quote := v2Quote(v2Factories[ChainIDEthereum][0], pair, reserves, weth, usdc, big.NewInt(10))
+	require.NotNil(t, quote)
+	assert.Equal(t, VenueUniswapV2, quote.Venue)
+	assert.Equal(t, constantProductOut(big.NewInt(10), big.NewInt(1_000), big.NewInt(2_000_000), 3000), quote.AmountOut)
+
+	assert.Nil(t, v2Quote(v2Factories[ChainIDEthereum][0], pair, CallResult{}, weth, usdc, big.NewInt(10)), "reverted read")
+
+	slot0 := CallResult{Success: true, ReturnData: encodeUint(new(big.Int).Mul(big.NewInt(2), q96))}
+	liquidity := CallResult{Success: true, ReturnData: encodeUint(big.NewInt(1000))}
+	quote = v3Quote(3000, pair, slot0, liquidity, usdc, weth, big.NewInt(1))
+	require.NotNil(t, quote)
+	assert.True(t, quote.Approximate)
+	assert.Nil(t, v3Quote(3000, pair, slot0, CallResult{Success: true, ReturnData: encodeUint(big.NewInt(0))}, usdc, weth, big.NewInt(1)))
+}
+
+func TestErc20BalancesAtBlock(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	require.NoError(t, err)
+	packed, err := parsed.Methods["aggregate3"].Outputs.Pack([]result3{
+		{Success: true, ReturnData: encodeUint(big.NewInt(7))},
+		{Success: true, ReturnData: encodeUint(big.NewInt(1_500_000))},
+	})
+	require.NoError(t, err)
+
+	var tags []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var tag string
+		require.NoError(t, json.Unmarshal(req.Params[1], &tag))
+		tags = append(tags, tag)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": "0x" + hex.EncodeToString(packed)})
+	}))
+	defer server.Close()
+	client := &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}
+
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	block := int64(100)
+	balances, err := client.erc20BalancesAt(context.Background(), 1, testWallet, []string{usdc}, &block)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), balances[common.Address{}.Hex()].Int64())
+	assert.Equal(t, int64(1_500_000), balances[usdc].Int64())
+
+	_, err = client.erc20Balances(context.Background(), 1, testWallet, []string{usdc})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x64", "latest"}, tags)
+}
@@ -0,0 +1,184 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/hexutil"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// nftTransferResult pairs a parsed NFT transfer with the native amount the wallet
+// paid (acquisitions) or received (disposals) in the same transaction
+type nftTransferResult struct {
+	transfer    *models.NFTTransfer
+	nativeValue float64
+}
+
+// getNFTTransfers fetches ERC-721/ERC-1155 transfers in both directions for an address
+func (c *AlchemyClient) getNFTTransfers(ctx context.Context, address string, chainID int) ([]nftTransferResult, error) {
+	baseURL, exists := c.baseURL(chainID)
+	_, custom := LookupCustomChain(chainID)
+	if !exists || custom || chainID == ChainIDPolygonAmoy {
+		return nil, fmt.Errorf("NFT transfers not supported on chain ID: %d", chainID)
+	}
+
+	// erc20 picks up wrapped native payments, which marketplaces settle offers and bids in
+	categories := []string{"external", "internal", "erc20", "erc721", "erc1155"}
+
+	incoming, err := c.getAssetTransfers(ctx, baseURL, map[string]interface{}{"toAddress": address, "category": categories})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incoming transfers: %w", err)
+	}
+	outgoing, err := c.getAssetTransfers(ctx, baseURL, map[string]interface{}{"fromAddress": address, "category": categories})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outgoing transfers: %w", err)
+	}
+
+	// Native value moved per transaction hash, by direction
+	nativeIn := make(map[string]float64)
+	nativeOut := make(map[string]float64)
+	for _, t := range incoming {
+		if isNativeConsideration(t, chainID) {
+			nativeIn[t.Hash] += t.Value
+		}
+	}
+	for _, t := range outgoing {
+		if isNativeConsideration(t, chainID) {
+			nativeOut[t.Hash] += t.Value
+		}
+	}
+
+	var results []nftTransferResult
+	for _, t := range incoming {
+		for _, transfer := range parseNFTTransfer(t, chainID, "in") {
+			results = append(results, nftTransferResult{transfer: transfer, nativeValue: nativeOut[t.Hash]})
+		}
+	}
+	for _, t := range outgoing {
+		for _, transfer := range parseNFTTransfer(t, chainID, "out") {
+			results = append(results, nftTransferResult{transfer: transfer, nativeValue: nativeIn[t.Hash]})
+		}
+	}
+
+	// A single sale/purchase can move several NFTs; split the consideration evenly
+	perTx := make(map[string]int)
+	for _, r := range results {
+		perTx[r.transfer.Direction+r.transfer.TransactionHash]++
+	}
+	for i := range results {
+		results[i].nativeValue /= float64(perTx[results[i].transfer.Direction+results[i].transfer.TransactionHash])
+	}
+
+	return results, nil
+}
+
+// isNativeConsideration reports whether a transfer moves the chain's native token or its
+// wrapped ERC-20, both of which are priced at the native token price
+func isNativeConsideration(t TransferData, chainID int) bool {
+	switch t.Category {
+	case "external", "internal":
+		return true
+	case "erc20":
+		wrapped, ok := wrappedNative[chainID]
+		return ok && strings.EqualFold(t.RawContract.Address, wrapped)
+	}
+	return false
+}
+
+// parseNFTTransfer converts an Alchemy transfer into NFT transfers; ERC-1155 batch
+// transfers yield one entry per token ID. Non-NFT categories yield nothing.
+func parseNFTTransfer(t TransferData, chainID int, direction string) []*models.NFTTransfer {
+	if t.Category != "erc721" && t.Category != "erc1155" {
+		return nil
+	}
+
+	blockNum, _ := hexutil.DecodeInt64(t.BlockNum)
+	timestamp, err := time.Parse(time.RFC3339, t.Metadata.BlockTimestamp)
+	if err != nil {
+		timestamp = time.Now()
+	}
+
+	base := models.NFTTransfer{
+		ChainID:         chainID,
+		ContractAddress: strings.ToLower(t.RawContract.Address),
+		Standard:        t.Category,
+		FromAddress:     t.From,
+		ToAddress:       t.To,
+		Direction:       direction,
+		TransactionHash: t.Hash,
+		BlockNumber:     blockNum,
+		Timestamp:       timestamp,
+	}
+
+	if t.Category == "erc721" {
+		transfer := base
+		transfer.TokenID = hexToDecimalString(t.ERC721TokenID)
+		transfer.Quantity = 1
+		return []*models.NFTTransfer{&transfer}
+	}
+
+	var transfers []*models.NFTTransfer
+	for _, meta := range t.ERC1155Metadata {
+		transfer := base
+		transfer.TokenID = hexToDecimalString(meta.TokenID)
+		quantity, err := hexutil.DecodeInt64(meta.Value)
+		if err != nil || quantity <= 0 {
+			quantity = 1
+		}
+		transfer.Quantity = quantity
+		transfers = append(transfers, &transfer)
+	}
+	return transfers
+}
+
+// hexToDecimalString converts a 0x-prefixed token ID to base 10, returning the input if it isn't hex
+func hexToDecimalString(hex string) string {
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(hex, "0x"), 16)
+	if !ok {
+		return hex
+	}
+	return n.String()
+}
+
+// GetNFTTransfers fetches NFT transfers for an address and prices the native
+// consideration of each at the historical native token price
+func (s *BlockchainService) GetNFTTransfers(ctx context.Context, address string, chainID int) ([]*models.NFTTransfer, error) {
+	results, err := s.alchemyClient.getNFTTransfers(ctx, address, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	cgID := NativeTokenCoinGeckoID(chainID)
+	prices := make(map[string]float64)
+	transfers := make([]*models.NFTTransfer, 0, len(results))
+
+	for _, r := range results {
+		if r.nativeValue > 0 {
+			day := r.transfer.Timestamp.UTC().Format(time.DateOnly)
+			price, ok := prices[day]
+			if !ok {
+				price, err = s.coinGeckoClient.GetHistoricalPrice(ctx, cgID, r.transfer.Timestamp)
+				if err != nil {
+					logger.Warn("Failed to price NFT consideration", "hash", r.transfer.TransactionHash, "error", err)
+				} else {
+					prices[day] = price
+					ok = true
+				}
+			}
+			if ok {
+				valueUSD := r.nativeValue * price
+				r.transfer.ValueUSD = &valueUSD
+			}
+		}
+		transfers = append(transfers, r.transfer)
+	}
+
+	s.fillNFTBlockHashes(ctx, chainID, transfers)
+
+	return transfers, nil
+}
@@ -0,0 +1,25 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNativeConsideration(t *testing.T) {
+	erc20 := func(address string) TransferData {
+		return TransferData{Category: "erc20", RawContract: RawContract{Address: address}}
+	}
+
+	assert.True(t, isNativeConsideration(TransferData{Category: "external"}, ChainIDEthereum))
+	assert.True(t, isNativeConsideration(TransferData{Category: "internal"}, ChainIDEthereum))
+
+	// WETH pays for offers and bids, whatever the case of its address
+	assert.True(t, isNativeConsideration(erc20("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"), ChainIDEthereum))
+	assert.True(t, isNativeConsideration(erc20("0x4200000000000000000000000000000000000006"), ChainIDOptimism))
+
+	// Other ERC-20s, and WETH's Ethereum address on another chain, aren't native
+	assert.False(t, isNativeConsideration(erc20("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"), ChainIDEthereum))
+	assert.False(t, isNativeConsideration(erc20("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"), ChainIDArbitrum))
+	assert.False(t, isNativeConsideration(TransferData{Category: "erc721"}, ChainIDEthereum))
+}
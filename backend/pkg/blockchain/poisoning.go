@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// poisoningAffixLen is how many leading and trailing hex digits a lookalike shares with
+// the address it imitates. Wallets shorten addresses to about this much, e.g. 0x1234…abcd.
+const poisoningAffixLen = 4
+
+// AddressPoisoning is a transfer suspected of poisoning a wallet's history: a zero-value
+// transfer with a counterparty made to look like one the wallet really deals with, left
+// to be copied from the history by mistake
+type AddressPoisoning struct {
+	Transaction *models.Transaction
+	// Counterparty is the lookalike address and LookalikeOf the one it imitates, both lowercase
+	Counterparty string
+	LookalikeOf  string
+}
+
+// DetectAddressPoisoning returns the zero-value transfers of the wallet at address whose
+// counterparty isn't one of known but looks like one, or like the wallet itself. Known
+// counterparties are those the wallet moved value with and addresses its owner knows,
+// such as their other wallets.
+func DetectAddressPoisoning(address string, known []string, transactions []*models.Transaction) []AddressPoisoning {
+	address = strings.ToLower(address)
+	knownSet := map[string]bool{address: true}
+	for _, k := range known {
+		knownSet[strings.ToLower(k)] = true
+	}
+
+	var suspects []AddressPoisoning
+	for _, tx := range transactions {
+		if !zeroValue(tx.Value) || tx.ToAddress == nil {
+			continue
+		}
+		counterparty := strings.ToLower(tx.FromAddress)
+		if counterparty == address {
+			counterparty = strings.ToLower(*tx.ToAddress)
+		}
+		if knownSet[counterparty] {
+			continue
+		}
+		// The wallet itself first: transfers "from" a lookalike of the wallet make its own
+		// address look like a counterparty
+		if lookalike(counterparty, address) {
+			suspects = append(suspects, AddressPoisoning{Transaction: tx, Counterparty: counterparty, LookalikeOf: address})
+			continue
+		}
+		for _, k := range known {
+			if k = strings.ToLower(k); lookalike(counterparty, k) {
+				suspects = append(suspects, AddressPoisoning{Transaction: tx, Counterparty: counterparty, LookalikeOf: k})
+				break
+			}
+		}
+	}
+	return suspects
+}
+
+// lookalike reports whether candidate is a different address sharing target's first and
+// last hex digits
+func lookalike(candidate, target string) bool {
+	if candidate == target || len(candidate) != 42 || len(target) != 42 || !strings.HasPrefix(candidate, "0x") {
+		return false
+	}
+	return candidate[2:2+poisoningAffixLen] == target[2:2+poisoningAffixLen] &&
+		candidate[42-poisoningAffixLen:] == target[42-poisoningAffixLen:]
+}
+
+// zeroValue reports whether a transfer's value is known to be zero
+func zeroValue(value *string) bool {
+	if value == nil {
+		return false
+	}
+	v, ok := new(big.Int).SetString(strings.TrimSpace(*value), 0)
+	return ok && v.Sign() == 0
+}
@@ -0,0 +1,40 @@
+package blockchain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectAddressPoisoning(t *testing.T) {
+	wallet := "0x8ba1f109551bd432803012645ac136ddd64dba72"
+	exchange := "0x28C6c06298d514Db089934071355E5743bf21d60"
+	// lookalike keeps an address's first and last four hex digits
+	lookalike := func(address, fill string) string {
+		return strings.ToLower(address[:6]) + strings.Repeat(fill, 32) + address[38:]
+	}
+	str := func(s string) *string { return &s }
+	transactions := []*models.Transaction{
+		// A lookalike of the exchange the wallet sends to, mimicking a zero-value send
+		{Hash: "0x01", FromAddress: wallet, ToAddress: str(lookalike(exchange, "f")), Value: str("0")},
+		// A lookalike of the wallet itself
+		{Hash: "0x02", FromAddress: lookalike(wallet, "0"), ToAddress: str(wallet), Value: str("0")},
+		// Transfers moving value, of unknown value or from unrelated addresses aren't poisoning
+		{Hash: "0x03", FromAddress: lookalike(exchange, "e"), ToAddress: str(wallet), Value: str("1000")},
+		{Hash: "0x04", FromAddress: lookalike(exchange, "d"), ToAddress: str(wallet)},
+		{Hash: "0x05", FromAddress: "0x1111111111111111111111111111111111111111", ToAddress: str(wallet), Value: str("0")},
+		{Hash: "0x06", FromAddress: exchange, ToAddress: str(wallet), Value: str("0")},
+	}
+
+	suspects := DetectAddressPoisoning(wallet, []string{exchange}, transactions)
+	require.Len(t, suspects, 2)
+	assert.Equal(t, "0x01", suspects[0].Transaction.Hash)
+	assert.Equal(t, lookalike(exchange, "f"), suspects[0].Counterparty)
+	assert.Equal(t, strings.ToLower(exchange), suspects[0].LookalikeOf)
+	assert.Equal(t, "0x02", suspects[1].Transaction.Hash)
+	assert.Equal(t, wallet, suspects[1].LookalikeOf)
+	assert.Empty(t, DetectAddressPoisoning(wallet, nil, nil))
+}
@@ -0,0 +1,506 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// On-chain liquidity venues the price-impact estimator reads directly
+const (
+	VenueUniswapV2 = "uniswap_v2"
+	VenueSushiSwap = "sushiswap"
+	VenueUniswapV3 = "uniswap_v3"
+	VenueCurve     = "curve"
+)
+
+// feeDenominator expresses pool fees in parts per million (Uniswap v3 "pips")
+const feeDenominator = 1_000_000
+
+type v2Factory struct {
+	venue   string
+	address string
+	fee     int64 // pips
+}
+
+var v2Factories = map[int][]v2Factory{
+	ChainIDEthereum: {
+		{VenueUniswapV2, "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f", 3000},
+		{VenueSushiSwap, "0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac", 3000},
+	},
+	ChainIDPolygon:  {{VenueSushiSwap, "0xc35DADB65012eC5796536bD9864eD8773aBc74C4", 3000}},
+	ChainIDArbitrum: {{VenueSushiSwap, "0xc35DADB65012eC5796536bD9864eD8773aBc74C4", 3000}},
+}
+
+var v3Factories = map[int]string{
+	ChainIDEthereum: "0x1F98431c8aD98523631AE4a59f267346ea31F984",
+	ChainIDPolygon:  "0x1F98431c8aD98523631AE4a59f267346ea31F984",
+	ChainIDArbitrum: "0x1F98431c8aD98523631AE4a59f267346ea31F984",
+	ChainIDOptimism: "0x1F98431c8aD98523631AE4a59f267346ea31F984",
+}
+
+var v3FeeTiers = []int64{100, 500, 3000, 10000}
+
+// curveRegistries are Curve MetaRegistry deployments
+var curveRegistries = map[int]string{
+	ChainIDEthereum: "0xF98B45FA17DE75FB1aD0e7aFD971b0ca00e379fC",
+}
+
+// wrappedNative is the ERC-20 pools hold in place of the chain's native token
+var wrappedNative = map[int]string{
+	ChainIDEthereum: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+	ChainIDPolygon:  "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270",
+	ChainIDArbitrum: "0x82aF49447D8a07e3bd95BD0d56f35241523fBab1",
+	ChainIDOptimism: "0x4200000000000000000000000000000000000006",
+}
+
+var (
+	selGetPair          = methodID("getPair(address,address)")
+	selGetReserves      = methodID("getReserves()")
+	selGetPool          = methodID("getPool(address,address,uint24)")
+	selSlot0            = methodID("slot0()")
+	selLiquidity        = methodID("liquidity()")
+	selFindPoolForCoins = methodID("find_pool_for_coins(address,address)")
+	selGetCoinIndices   = methodID("get_coin_indices(address,address,address)")
+	selGetDy            = methodID("get_dy(int128,int128,uint256)")
+	selGetDyUnderlying  = methodID("get_dy_underlying(int128,int128,uint256)")
+	selGetDyUint        = methodID("get_dy(uint256,uint256,uint256)")
+)
+
+// q96 is 2^96, the fixed-point scale of Uniswap v3 sqrt prices
+var q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+// PoolQuote is an estimated swap through a single on-chain pool
+type PoolQuote struct {
+	Venue   string
+	Pool    string
+	FeeTier int64 // Uniswap v3 fee tier in pips, 0 for other venues
+	Fee     int64 // pips; 0 when the venue's quote already includes it (Curve)
+	// AmountOut is in the output token's base units
+	AmountOut *big.Int
+	// PriceImpact is the percent shortfall against the pool's marginal price, excluding fees
+	PriceImpact float64
+	// Approximate is set for Uniswap v3, whose estimate ignores liquidity changes across ticks
+	Approximate bool
+}
+
+// SupportsPoolQuotes reports whether any on-chain venue is configured for the chain
+func SupportsPoolQuotes(chainID int) bool {
+	_, v2 := v2Factories[chainID]
+	_, v3 := v3Factories[chainID]
+	_, curve := curveRegistries[chainID]
+	return v2 || v3 || curve
+}
+
+// QuotePools estimates swapping amountIn of tokenIn for tokenOut through every known pool
+// for the pair, reading reserves and prices straight from the chain. Venues without a pool
+// for the pair are skipped; an empty result means no direct pool exists.
+func (s *BlockchainService) QuotePools(ctx context.Context, chainID int, tokenIn, tokenOut string, amountIn *big.Int) ([]*PoolQuote, error) {
+	if !SupportsPoolQuotes(chainID) {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+	tokenIn, tokenOut = poolToken(chainID, tokenIn), poolToken(chainID, tokenOut)
+	if strings.EqualFold(tokenIn, tokenOut) {
+		return nil, fmt.Errorf("tokens must differ")
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		quotes []*PoolQuote
+	)
+	collect := func(venue string, quote *PoolQuote, err error) {
+		if err != nil {
+			logger.Debug("Pool quote unavailable", "venue", venue, "chainID", chainID, "error", err)
+			return
+		}
+		if quote == nil || quote.AmountOut.Sign() == 0 {
+			return
+		}
+		mu.Lock()
+		quotes = append(quotes, quote)
+		mu.Unlock()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		uniswapQuotes, err := s.alchemyClient.quoteUniswapPools(ctx, chainID, tokenIn, tokenOut, amountIn)
+		for _, quote := range uniswapQuotes {
+			collect(quote.Venue, quote, nil)
+		}
+		if err != nil {
+			collect("uniswap", nil, err)
+		}
+	}()
+	if registry, ok := curveRegistries[chainID]; ok {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quote, err := s.alchemyClient.quoteCurve(ctx, chainID, registry, tokenIn, tokenOut, amountIn)
+			collect(VenueCurve, quote, err)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// poolToken maps the native token placeholders to the chain's wrapped native token
+func poolToken(chainID int, token string) string {
+	if strings.EqualFold(token, "0x0000000000000000000000000000000000000000") ||
+		strings.EqualFold(token, "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE") {
+		if wrapped, ok := wrappedNative[chainID]; ok {
+			return wrapped
+		}
+	}
+	return token
+}
+
+// quoteUniswapPools quotes every Uniswap v2-style pair and v3 fee tier for the pair in
+// two Multicall3 batches: one finding the pools, one reading their reserves and prices
+func (c *AlchemyClient) quoteUniswapPools(ctx context.Context, chainID int, tokenIn, tokenOut string, amountIn *big.Int) ([]*PoolQuote, error) {
+	factories := v2Factories[chainID]
+	v3Factory, hasV3 := v3Factories[chainID]
+	var tiers []int64
+	if hasV3 {
+		tiers = v3FeeTiers
+	}
+
+	lookups := make([]Call, 0, len(factories)+len(tiers))
+	for _, factory := range factories {
+		lookups = append(lookups, newCall(factory.address, selGetPair, encodeAddress(tokenIn), encodeAddress(tokenOut)))
+	}
+	for _, tier := range tiers {
+		lookups = append(lookups, newCall(v3Factory, selGetPool, encodeAddress(tokenIn), encodeAddress(tokenOut), encodeUint(big.NewInt(tier))))
+	}
+	if len(lookups) == 0 {
+		return nil, nil
+	}
+	found, err := c.multicall(ctx, chainID, lookups)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pools that exist, with the index of their first state read
+	type pool struct {
+		address common.Address
+		factory *v2Factory
+		tier    int64
+		read    int
+	}
+	var pools []pool
+	var reads []Call
+	for i, result := range found {
+		if !result.Success || len(result.ReturnData) < 32 {
+			continue
+		}
+		address := decodeAddress(result.ReturnData, 0)
+		if address == (common.Address{}) {
+			continue
+		}
+		if i < len(factories) {
+			pools = append(pools, pool{address: address, factory: &factories[i], read: len(reads)})
+			reads = append(reads, newCall(address.Hex(), selGetReserves))
+			continue
+		}
+		pools = append(pools, pool{address: address, tier: tiers[i-len(factories)], read: len(reads)})
+		reads = append(reads, newCall(address.Hex(), selSlot0), newCall(address.Hex(), selLiquidity))
+	}
+	if len(reads) == 0 {
+		return nil, nil
+	}
+	state, err := c.multicall(ctx, chainID, reads)
+	if err != nil {
+		return nil, err
+	}
+
+	var quotes []*PoolQuote
+	for _, p := range pools {
+		var quote *PoolQuote
+		if p.factory != nil {
+			quote = v2Quote(*p.factory, p.address, state[p.read], tokenIn, tokenOut, amountIn)
+		} else {
+			quote = v3Quote(p.tier, p.address, state[p.read], state[p.read+1], tokenIn, tokenOut, amountIn)
+		}
+		if quote != nil {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, nil
+}
+
+// v2Quote quotes a constant-product pair from its getReserves() result
+func v2Quote(factory v2Factory, pair common.Address, reserves CallResult, tokenIn, tokenOut string, amountIn *big.Int) *PoolQuote {
+	if !reserves.Success || len(reserves.ReturnData) < 64 {
+		return nil
+	}
+	reserve0, reserve1 := decodeUint(reserves.ReturnData, 0), decodeUint(reserves.ReturnData, 1)
+	reserveIn, reserveOut := reserve0, reserve1
+	if !isToken0(tokenIn, tokenOut) {
+		reserveIn, reserveOut = reserve1, reserve0
+	}
+	if reserveIn.Sign() == 0 || reserveOut.Sign() == 0 {
+		return nil
+	}
+
+	return &PoolQuote{
+		Venue:       factory.venue,
+		Pool:        pair.Hex(),
+		Fee:         factory.fee,
+		AmountOut:   constantProductOut(amountIn, reserveIn, reserveOut, factory.fee),
+		PriceImpact: constantProductImpact(amountIn, reserveIn, factory.fee),
+	}
+}
+
+// v3Quote quotes a Uniswap v3 pool from its slot0() and liquidity() results
+func v3Quote(tier int64, pool common.Address, slot0, liquidityResult CallResult, tokenIn, tokenOut string, amountIn *big.Int) *PoolQuote {
+	sqrtPriceX96, liquidity := uintResult(slot0), uintResult(liquidityResult)
+	if sqrtPriceX96 == nil || liquidity == nil || liquidity.Sign() == 0 || sqrtPriceX96.Sign() == 0 {
+		return nil
+	}
+
+	reserve0, reserve1 := v3VirtualReserves(liquidity, sqrtPriceX96)
+	reserveIn, reserveOut := reserve0, reserve1
+	if !isToken0(tokenIn, tokenOut) {
+		reserveIn, reserveOut = reserve1, reserve0
+	}
+	if reserveIn.Sign() == 0 || reserveOut.Sign() == 0 {
+		return nil
+	}
+
+	return &PoolQuote{
+		Venue:       VenueUniswapV3,
+		Pool:        pool.Hex(),
+		FeeTier:     tier,
+		Fee:         tier,
+		AmountOut:   constantProductOut(amountIn, reserveIn, reserveOut, tier),
+		PriceImpact: constantProductImpact(amountIn, reserveIn, tier),
+		Approximate: true,
+	}
+}
+
+func (c *AlchemyClient) quoteCurve(ctx context.Context, chainID int, registry, tokenIn, tokenOut string, amountIn *big.Int) (*PoolQuote, error) {
+	out, err := c.ethCall(ctx, chainID, registry, selFindPoolForCoins, encodeAddress(tokenIn), encodeAddress(tokenOut))
+	if err != nil {
+		return nil, err
+	}
+	pool := decodeAddress(out, 0)
+	if pool == (common.Address{}) {
+		return nil, nil
+	}
+
+	out, err = c.ethCall(ctx, chainID, registry, selGetCoinIndices, encodeAddress(pool.Hex()), encodeAddress(tokenIn), encodeAddress(tokenOut))
+	if err != nil {
+		return nil, err
+	}
+	i, j := decodeUint(out, 0), decodeUint(out, 1)
+	underlying := decodeUint(out, 2).Sign() != 0
+
+	// A tiny reference trade stands in for the marginal price, since Curve exposes no spot price
+	refIn := new(big.Int).Div(amountIn, big.NewInt(10000))
+	if refIn.Sign() == 0 {
+		refIn = big.NewInt(1)
+	}
+
+	getDy := func(amount *big.Int) (*big.Int, error) {
+		selector := selGetDy
+		if underlying {
+			selector = selGetDyUnderlying
+		}
+		out, err := c.ethCall(ctx, chainID, pool.Hex(), selector, encodeUint(i), encodeUint(j), encodeUint(amount))
+		if err != nil && !underlying {
+			// Crypto pools index coins with uint256
+			out, err = c.ethCall(ctx, chainID, pool.Hex(), selGetDyUint, encodeUint(i), encodeUint(j), encodeUint(amount))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return decodeUint(out, 0), nil
+	}
+
+	amountOut, err := getDy(amountIn)
+	if err != nil {
+		return nil, err
+	}
+	refOut, err := getDy(refIn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PoolQuote{
+		Venue:       VenueCurve,
+		Pool:        pool.Hex(),
+		AmountOut:   amountOut,
+		PriceImpact: quoteImpact(amountIn, amountOut, refIn, refOut),
+	}, nil
+}
+
+// constantProductOut is the x*y=k output for amountIn after the fee (in pips)
+func constantProductOut(amountIn, reserveIn, reserveOut *big.Int, fee int64) *big.Int {
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(feeDenominator-fee))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(feeDenominator))
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Div(numerator, denominator)
+}
+
+// constantProductImpact is the percent by which an x*y=k execution price falls short
+// of the marginal price, which works out to dx / (x + dx) for the post-fee input dx
+func constantProductImpact(amountIn, reserveIn *big.Int, fee int64) float64 {
+	dx := new(big.Float).SetInt(amountIn)
+	dx.Mul(dx, big.NewFloat(float64(feeDenominator-fee)/feeDenominator))
+	total := new(big.Float).Add(new(big.Float).SetInt(reserveIn), dx)
+	impact, _ := new(big.Float).Quo(dx, total).Float64()
+	return impact * 100
+}
+
+// quoteImpact compares the execution rate of a quote with that of a reference-sized quote
+func quoteImpact(amountIn, amountOut, refIn, refOut *big.Int) float64 {
+	if amountIn.Sign() == 0 || refIn.Sign() == 0 || refOut.Sign() == 0 {
+		return 0
+	}
+	rate := new(big.Float).Quo(new(big.Float).SetInt(amountOut), new(big.Float).SetInt(amountIn))
+	refRate := new(big.Float).Quo(new(big.Float).SetInt(refOut), new(big.Float).SetInt(refIn))
+	ratio, _ := new(big.Float).Quo(rate, refRate).Float64()
+	if ratio >= 1 {
+		return 0
+	}
+	return (1 - ratio) * 100
+}
+
+// v3VirtualReserves converts in-range liquidity to the equivalent x*y=k reserves:
+// reserve0 = L / sqrtP and reserve1 = L * sqrtP
+func v3VirtualReserves(liquidity, sqrtPriceX96 *big.Int) (*big.Int, *big.Int) {
+	reserve0 := new(big.Int).Mul(liquidity, q96)
+	reserve0.Div(reserve0, sqrtPriceX96)
+	reserve1 := new(big.Int).Mul(liquidity, sqrtPriceX96)
+	reserve1.Div(reserve1, q96)
+	return reserve0, reserve1
+}
+
+// isToken0 reports whether tokenIn sorts first, as Uniswap orders pair tokens
+func isToken0(tokenIn, tokenOut string) bool {
+	return bytes.Compare(common.HexToAddress(tokenIn).Bytes(), common.HexToAddress(tokenOut).Bytes()) < 0
+}
+
+// ethCall runs a read-only contract call against the latest block
+func (c *AlchemyClient) ethCall(ctx context.Context, chainID int, to string, selector []byte, args ...[]byte) ([]byte, error) {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	return c.ethCallFrom(ctx, chainID, "", to, data)
+}
+
+// ethCallFrom runs eth_call as from, which contracts checking msg.sender need; an empty
+// from leaves the sender to the node
+func (c *AlchemyClient) ethCallFrom(ctx context.Context, chainID int, from, to string, data []byte) ([]byte, error) {
+	return c.ethCallAt(ctx, chainID, from, to, data, nil)
+}
+
+// ethCallAt runs eth_call against the state at the given block, or at the latest block if nil
+func (c *AlchemyClient) ethCallAt(ctx context.Context, chainID int, from, to string, data []byte, block *int64) ([]byte, error) {
+	baseURL, exists := c.baseURL(chainID)
+	if !exists {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	call := map[string]string{
+		"to":   to,
+		"data": "0x" + hex.EncodeToString(data),
+	}
+	if from != "" {
+		call["from"] = from
+	}
+	reqBody := map[string]interface{}{
+		"id":      1,
+		"jsonrpc": "2.0",
+		"method":  "eth_call",
+		"params":  []interface{}{call, blockTag(block)},
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var callResp struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&callResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if callResp.Error != nil {
+		return nil, fmt.Errorf("RPC error: %s", callResp.Error.Message)
+	}
+
+	result, err := hex.DecodeString(strings.TrimPrefix(callResp.Result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid call result: %w", err)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("empty call result")
+	}
+	return result, nil
+}
+
+func methodID(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+func encodeAddress(address string) []byte {
+	return common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)
+}
+
+func encodeUint(v *big.Int) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+// decodeUint returns the i-th 32-byte word of an ABI-encoded result, or zero if it is missing
+func decodeUint(data []byte, i int) *big.Int {
+	if len(data) < (i+1)*32 {
+		return new(big.Int)
+	}
+	return new(big.Int).SetBytes(data[i*32 : (i+1)*32])
+}
+
+func decodeAddress(data []byte, i int) common.Address {
+	if len(data) < (i+1)*32 {
+		return common.Address{}
+	}
+	return common.BytesToAddress(data[i*32 : (i+1)*32])
+}
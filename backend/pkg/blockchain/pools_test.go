@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodID(t *testing.T) {
+	assert.Equal(t, "e6a43905", hex.EncodeToString(selGetPair))
+	assert.Equal(t, "0902f1ac", hex.EncodeToString(selGetReserves))
+	assert.Equal(t, "1698ee82", hex.EncodeToString(selGetPool))
+}
+
+func TestConstantProduct(t *testing.T) {
+	reserveIn := big.NewInt(1_000_000)
+	reserveOut := big.NewInt(2_000_000)
+
+	// 10% of the pool with no fee: out = 2M * 100k / 1.1M, impact = 100k / 1.1M
+	out := constantProductOut(big.NewInt(100_000), reserveIn, reserveOut, 0)
+	assert.Equal(t, "181818", out.String())
+	assert.InDelta(t, 9.0909, constantProductImpact(big.NewInt(100_000), reserveIn, 0), 1e-4)
+
+	// The 0.3% fee reduces output but is not counted as impact
+	withFee := constantProductOut(big.NewInt(100_000), reserveIn, reserveOut, 3000)
+	assert.True(t, withFee.Cmp(out) < 0)
+	assert.InDelta(t, 9.0661, constantProductImpact(big.NewInt(100_000), reserveIn, 3000), 1e-4)
+}
+
+func TestV3VirtualReserves(t *testing.T) {
+	// sqrtP = 2 (price 4 token1 per token0) and L = 1000: x = 500, y = 2000
+	sqrtPriceX96 := new(big.Int).Mul(big.NewInt(2), q96)
+	reserve0, reserve1 := v3VirtualReserves(big.NewInt(1000), sqrtPriceX96)
+	assert.Equal(t, int64(500), reserve0.Int64())
+	assert.Equal(t, int64(2000), reserve1.Int64())
+}
+
+func TestQuoteImpact(t *testing.T) {
+	// Reference rate 2.0, executed rate 1.9: 5% impact
+	assert.InDelta(t, 5.0, quoteImpact(big.NewInt(1000), big.NewInt(1900), big.NewInt(10), big.NewInt(20)), 1e-9)
+	// Better than reference rounds to no impact
+	assert.Equal(t, 0.0, quoteImpact(big.NewInt(1000), big.NewInt(2100), big.NewInt(10), big.NewInt(20)))
+}
+
+func TestPoolTokenOrdering(t *testing.T) {
+	weth := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	assert.True(t, isToken0(usdc, weth))
+	assert.False(t, isToken0(weth, usdc))
+	assert.Equal(t, weth, poolToken(ChainIDEthereum, "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"))
+	assert.Equal(t, usdc, poolToken(ChainIDEthereum, usdc))
+}
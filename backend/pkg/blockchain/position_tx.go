@@ -0,0 +1,363 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Protocols position transactions can be built for
+const (
+	PositionProtocolAaveV3     = "aave-v3"
+	PositionProtocolCompoundV3 = "compound-v3"
+	PositionProtocolERC4626    = "erc4626"
+)
+
+// aaveV3RewardsControllers are the Aave v3 incentives controllers per chain
+var aaveV3RewardsControllers = map[int]string{
+	ChainIDEthereum: "0x8164Cc65827dcFe994AB23944CBC90e0aa80bFcb",
+	ChainIDPolygon:  "0x929EC64c34a17401F460460D4B9390518E5B473e",
+	ChainIDArbitrum: "0x929EC64c34a17401F460460D4B9390518E5B473e",
+	ChainIDOptimism: "0x929EC64c34a17401F460460D4B9390518E5B473e",
+}
+
+// compoundV3Rewards are the CometRewards contracts per chain
+var compoundV3Rewards = map[int]string{
+	ChainIDEthereum: "0x1B0e765F6224C21223AeA2af16c1C46E38885a40",
+	ChainIDPolygon:  "0x45939657d1CA34A8FA39A924B71D28Fe8431e581",
+	ChainIDArbitrum: "0x88730d254A2f7e6AC8388c3198aFd694bA9f7fae",
+	ChainIDOptimism: "0x443EA0340cb75a160F31A440722dec7b5bc3C2E9",
+}
+
+var (
+	selApprove            = methodID("approve(address,uint256)")
+	selAaveSupply         = methodID("supply(address,uint256,address,uint16)")
+	selAaveWithdraw       = methodID("withdraw(address,uint256,address)")
+	selAaveClaimAllToSelf = methodID("claimAllRewardsToSelf(address[])")
+	selCometSupply        = methodID("supply(address,uint256)")
+	selCometWithdraw      = methodID("withdraw(address,uint256)")
+	selCometClaim         = methodID("claim(address,address,bool)")
+	selVaultDeposit       = methodID("deposit(uint256,address)")
+	selVaultWithdraw      = methodID("withdraw(uint256,address,address)")
+	selVaultRedeem        = methodID("redeem(uint256,address,address)")
+	maxUint256            = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+)
+
+// PositionTx is an unsigned transaction for the position owner to sign and send, in order
+type PositionTx struct {
+	ChainID     int    `json:"chainId"`
+	To          string `json:"to"`
+	Data        string `json:"data"`
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// PositionTxRequest describes a deposit into or withdrawal from a market
+type PositionTxRequest struct {
+	ChainID int
+	// Market is the pool, Comet or vault contract; Aave v3 markets default to the chain's Pool
+	Market string
+	// Asset is the ERC-20 token deposited and withdrawn
+	Asset string
+	// Amount is in base units of Asset; nil withdraws the whole position where the
+	// protocol allows it
+	Amount *big.Int
+	// Shares redeems that many ERC-4626 vault shares when Amount is nil, so a vault can be
+	// left entirely
+	Shares *big.Int
+	Owner  string
+	// Receipt is the token the market issues for the deposit, e.g. the aToken; Aave v3
+	// reward claims need it
+	Receipt string
+	// Claim adds a reward claim to an exit
+	Claim bool
+}
+
+// positionTxBuilder builds the transactions entering and leaving one protocol's markets
+type positionTxBuilder interface {
+	// Enter approves the market to take the asset and deposits it
+	Enter(req PositionTxRequest) ([]PositionTx, error)
+	// Exit withdraws the asset, claiming rewards first when asked
+	Exit(req PositionTxRequest) ([]PositionTx, error)
+	// Claim claims the position's rewards
+	Claim(req PositionTxRequest) ([]PositionTx, error)
+}
+
+var positionTxBuilders = map[string]positionTxBuilder{
+	PositionProtocolAaveV3:     aaveV3TxBuilder{},
+	PositionProtocolCompoundV3: compoundV3TxBuilder{},
+	PositionProtocolERC4626:    erc4626TxBuilder{},
+}
+
+// SupportsPositionTxs reports whether transactions can be built for the protocol
+func SupportsPositionTxs(protocol string) bool {
+	_, ok := positionTxBuilders[protocol]
+	return ok
+}
+
+// BuildEnterPositionTxs returns the approve and deposit transactions entering a market
+func BuildEnterPositionTxs(protocol string, req PositionTxRequest) ([]PositionTx, error) {
+	builder, ok := positionTxBuilders[protocol]
+	if !ok {
+		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
+	}
+	if req.Amount == nil || req.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("deposit amount must be positive")
+	}
+	if err := checkPositionAddresses(req); err != nil {
+		return nil, err
+	}
+	return builder.Enter(req)
+}
+
+// BuildExitPositionTxs returns the transactions leaving a market, claiming rewards first
+// when the request asks for it
+func BuildExitPositionTxs(protocol string, req PositionTxRequest) ([]PositionTx, error) {
+	builder, ok := positionTxBuilders[protocol]
+	if !ok {
+		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
+	}
+	if req.Amount != nil && req.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("withdrawal amount must be positive")
+	}
+	if err := checkPositionAddresses(req); err != nil {
+		return nil, err
+	}
+	return builder.Exit(req)
+}
+
+// BuildClaimPositionTxs returns the transactions claiming a position's rewards
+func BuildClaimPositionTxs(protocol string, req PositionTxRequest) ([]PositionTx, error) {
+	builder, ok := positionTxBuilders[protocol]
+	if !ok {
+		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
+	}
+	if !common.IsHexAddress(req.Owner) {
+		return nil, fmt.Errorf("invalid owner address: %q", req.Owner)
+	}
+	if req.Receipt != "" && !common.IsHexAddress(req.Receipt) {
+		return nil, fmt.Errorf("invalid receipt token address: %q", req.Receipt)
+	}
+	return builder.Claim(req)
+}
+
+// AaveV3Pool returns the Aave v3 Pool deployed on the chain
+func AaveV3Pool(chainID int) (string, bool) {
+	pool, ok := aaveV3Pools[chainID]
+	return pool, ok
+}
+
+// AaveV3RewardsController returns the Aave v3 incentives controller on the chain
+func AaveV3RewardsController(chainID int) (string, bool) {
+	controller, ok := aaveV3RewardsControllers[chainID]
+	return controller, ok
+}
+
+func checkPositionAddresses(req PositionTxRequest) error {
+	for name, addr := range map[string]string{"owner": req.Owner, "asset": req.Asset} {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid %s address: %q", name, addr)
+		}
+	}
+	if req.Market != "" && !common.IsHexAddress(req.Market) {
+		return fmt.Errorf("invalid market address: %q", req.Market)
+	}
+	if req.Receipt != "" && !common.IsHexAddress(req.Receipt) {
+		return fmt.Errorf("invalid receipt token address: %q", req.Receipt)
+	}
+	return nil
+}
+
+type aaveV3TxBuilder struct{}
+
+func (aaveV3TxBuilder) market(req PositionTxRequest) (string, error) {
+	pool, ok := aaveV3Pools[req.ChainID]
+	if !ok {
+		return "", fmt.Errorf("aave v3 is not deployed on chain %d", req.ChainID)
+	}
+	if req.Market != "" && !strings.EqualFold(req.Market, pool) {
+		return "", fmt.Errorf("%s is not the aave v3 pool on chain %d", req.Market, req.ChainID)
+	}
+	return pool, nil
+}
+
+func (b aaveV3TxBuilder) Enter(req PositionTxRequest) ([]PositionTx, error) {
+	pool, err := b.market(req)
+	if err != nil {
+		return nil, err
+	}
+	return []PositionTx{
+		approveTx(req.ChainID, req.Asset, pool, req.Amount),
+		positionTx(req.ChainID, pool, "Supply to Aave v3", selAaveSupply,
+			encodeAddress(req.Asset), encodeUint(req.Amount), encodeAddress(req.Owner), encodeUint(big.NewInt(0))),
+	}, nil
+}
+
+func (b aaveV3TxBuilder) Exit(req PositionTxRequest) ([]PositionTx, error) {
+	pool, err := b.market(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []PositionTx
+	if req.Claim {
+		if txs, err = b.Claim(req); err != nil {
+			return nil, err
+		}
+	}
+
+	// The maximum amount withdraws the whole balance, interest included
+	amount, desc := withdrawAmount(req.Amount)
+	return append(txs, positionTx(req.ChainID, pool, desc+" from Aave v3", selAaveWithdraw,
+		encodeAddress(req.Asset), encodeUint(amount), encodeAddress(req.Owner))), nil
+}
+
+func (aaveV3TxBuilder) Claim(req PositionTxRequest) ([]PositionTx, error) {
+	controller, ok := aaveV3RewardsControllers[req.ChainID]
+	if !ok {
+		return nil, fmt.Errorf("aave v3 rewards are not configured on chain %d", req.ChainID)
+	}
+	if req.Receipt == "" {
+		return nil, fmt.Errorf("claiming aave v3 rewards needs the aToken address")
+	}
+	// claimAllRewardsToSelf(address[]): offset to the array, its length, then the aToken
+	return []PositionTx{
+		positionTx(req.ChainID, controller, "Claim Aave v3 rewards", selAaveClaimAllToSelf,
+			encodeUint(big.NewInt(32)), encodeUint(big.NewInt(1)), encodeAddress(req.Receipt)),
+	}, nil
+}
+
+type compoundV3TxBuilder struct{}
+
+// market returns the Comet named by the request, or the chain's only Comet when none is
+func (compoundV3TxBuilder) market(req PositionTxRequest) (string, error) {
+	markets := compoundV3Markets[req.ChainID]
+	if req.Market == "" {
+		if len(markets) != 1 {
+			return "", fmt.Errorf("compound v3 market address is required on chain %d", req.ChainID)
+		}
+		return markets[0], nil
+	}
+	for _, market := range markets {
+		if strings.EqualFold(market, req.Market) {
+			return market, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a known compound v3 market on chain %d", req.Market, req.ChainID)
+}
+
+func (b compoundV3TxBuilder) Enter(req PositionTxRequest) ([]PositionTx, error) {
+	comet, err := b.market(req)
+	if err != nil {
+		return nil, err
+	}
+	return []PositionTx{
+		approveTx(req.ChainID, req.Asset, comet, req.Amount),
+		positionTx(req.ChainID, comet, "Supply to Compound v3", selCometSupply,
+			encodeAddress(req.Asset), encodeUint(req.Amount)),
+	}, nil
+}
+
+func (b compoundV3TxBuilder) Exit(req PositionTxRequest) ([]PositionTx, error) {
+	comet, err := b.market(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []PositionTx
+	if req.Claim {
+		if txs, err = b.Claim(req); err != nil {
+			return nil, err
+		}
+	}
+
+	// The maximum amount withdraws the whole base balance
+	amount, desc := withdrawAmount(req.Amount)
+	return append(txs, positionTx(req.ChainID, comet, desc+" from Compound v3", selCometWithdraw,
+		encodeAddress(req.Asset), encodeUint(amount))), nil
+}
+
+func (b compoundV3TxBuilder) Claim(req PositionTxRequest) ([]PositionTx, error) {
+	comet, err := b.market(req)
+	if err != nil {
+		return nil, err
+	}
+	rewards, ok := compoundV3Rewards[req.ChainID]
+	if !ok {
+		return nil, fmt.Errorf("compound v3 rewards are not configured on chain %d", req.ChainID)
+	}
+	return []PositionTx{
+		positionTx(req.ChainID, rewards, "Claim Compound v3 rewards", selCometClaim,
+			encodeAddress(comet), encodeAddress(req.Owner), encodeUint(big.NewInt(1))),
+	}, nil
+}
+
+type erc4626TxBuilder struct{}
+
+func (erc4626TxBuilder) Enter(req PositionTxRequest) ([]PositionTx, error) {
+	if req.Market == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	return []PositionTx{
+		approveTx(req.ChainID, req.Asset, req.Market, req.Amount),
+		positionTx(req.ChainID, req.Market, "Deposit into vault", selVaultDeposit,
+			encodeUint(req.Amount), encodeAddress(req.Owner)),
+	}, nil
+}
+
+func (e erc4626TxBuilder) Exit(req PositionTxRequest) ([]PositionTx, error) {
+	if req.Market == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if req.Claim {
+		return e.Claim(req)
+	}
+	// Withdrawing everything redeems every share, as the assets they're worth keep changing
+	if req.Amount == nil {
+		if req.Shares == nil || req.Shares.Sign() <= 0 {
+			return nil, fmt.Errorf("vault withdrawals need an amount or the shares to redeem")
+		}
+		return []PositionTx{
+			positionTx(req.ChainID, req.Market, "Redeem all vault shares", selVaultRedeem,
+				encodeUint(req.Shares), encodeAddress(req.Owner), encodeAddress(req.Owner)),
+		}, nil
+	}
+	return []PositionTx{
+		positionTx(req.ChainID, req.Market, "Withdraw from vault", selVaultWithdraw,
+			encodeUint(req.Amount), encodeAddress(req.Owner), encodeAddress(req.Owner)),
+	}, nil
+}
+
+func (erc4626TxBuilder) Claim(PositionTxRequest) ([]PositionTx, error) {
+	return nil, fmt.Errorf("vaults have no rewards to claim")
+}
+
+// withdrawAmount returns the amount to withdraw, the maximum standing for everything
+func withdrawAmount(amount *big.Int) (*big.Int, string) {
+	if amount == nil {
+		return maxUint256, "Withdraw everything"
+	}
+	return amount, "Withdraw"
+}
+
+// approveTx approves spender to take exactly amount of token, rather than an unlimited allowance
+func approveTx(chainID int, token, spender string, amount *big.Int) PositionTx {
+	return positionTx(chainID, token, "Approve token", selApprove, encodeAddress(spender), encodeUint(amount))
+}
+
+func positionTx(chainID int, to, description string, selector []byte, args ...[]byte) PositionTx {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	return PositionTx{
+		ChainID:     chainID,
+		To:          strings.ToLower(to),
+		Data:        hexutil.Encode(data),
+		Value:       "0",
+		Description: description,
+	}
+}
@@ -0,0 +1,173 @@
+package blockchain
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOwner = "0x1111111111111111111111111111111111111111"
+	testUSDC  = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	testVault = "0x2222222222222222222222222222222222222222"
+)
+
+func TestBuildEnterPositionTxsAave(t *testing.T) {
+	txs, err := BuildEnterPositionTxs(PositionProtocolAaveV3, PositionTxRequest{
+		ChainID: ChainIDEthereum,
+		Asset:   testUSDC,
+		Amount:  big.NewInt(1_000_000),
+		Owner:   testOwner,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	pool := strings.ToLower(aaveV3Pools[ChainIDEthereum])
+	approve := txs[0]
+	assert.Equal(t, strings.ToLower(testUSDC), approve.To)
+	assert.Equal(t, "0x095ea7b3"+word(pool[2:])+word("f4240"), approve.Data)
+
+	supply := txs[1]
+	assert.Equal(t, pool, supply.To)
+	assert.Equal(t, "0x617ba037"+word(strings.ToLower(testUSDC[2:]))+word("f4240")+word(testOwner[2:])+word(""), supply.Data)
+	assert.Equal(t, "0", supply.Value)
+}
+
+func TestBuildExitPositionTxsAave(t *testing.T) {
+	aToken := "0x98C23E9d8f34FEFb1B7BD6a91B7FF122F4e16F5c"
+	txs, err := BuildExitPositionTxs(PositionProtocolAaveV3, PositionTxRequest{
+		ChainID: ChainIDEthereum,
+		Asset:   testUSDC,
+		Owner:   testOwner,
+		Receipt: aToken,
+		Claim:   true,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	claim := txs[0]
+	assert.Equal(t, strings.ToLower(aaveV3RewardsControllers[ChainIDEthereum]), claim.To)
+	// The address array is encoded after its offset and length
+	assert.Equal(t, word("20")+word("1")+word(strings.ToLower(aToken[2:])), claim.Data[10:])
+
+	// No amount withdraws everything
+	withdraw := txs[1]
+	assert.Equal(t, "0x69328dec"+word(strings.ToLower(testUSDC[2:]))+strings.Repeat("f", 64)+word(testOwner[2:]), withdraw.Data)
+
+	// Claiming needs the aToken
+	_, err = BuildExitPositionTxs(PositionProtocolAaveV3, PositionTxRequest{
+		ChainID: ChainIDEthereum, Asset: testUSDC, Owner: testOwner, Claim: true,
+	})
+	assert.Error(t, err)
+
+	// Only the chain's Pool is accepted as the market
+	_, err = BuildExitPositionTxs(PositionProtocolAaveV3, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: testVault, Asset: testUSDC, Owner: testOwner,
+	})
+	assert.Error(t, err)
+}
+
+func TestBuildPositionTxsCompound(t *testing.T) {
+	comet := compoundV3Markets[ChainIDEthereum][0]
+
+	// Ethereum has several Comets, so the market must be named
+	_, err := BuildEnterPositionTxs(PositionProtocolCompoundV3, PositionTxRequest{
+		ChainID: ChainIDEthereum, Asset: testUSDC, Amount: big.NewInt(5), Owner: testOwner,
+	})
+	assert.Error(t, err)
+
+	txs, err := BuildEnterPositionTxs(PositionProtocolCompoundV3, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: comet, Asset: testUSDC, Amount: big.NewInt(5), Owner: testOwner,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, strings.ToLower(comet), txs[1].To)
+	assert.Equal(t, "0xf2b9fdb8"+word(strings.ToLower(testUSDC[2:]))+word("5"), txs[1].Data)
+
+	// Polygon has a single Comet, which is used when none is named
+	txs, err = BuildExitPositionTxs(PositionProtocolCompoundV3, PositionTxRequest{
+		ChainID: ChainIDPolygon, Asset: testUSDC, Amount: big.NewInt(5), Owner: testOwner, Claim: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, strings.ToLower(compoundV3Rewards[ChainIDPolygon]), txs[0].To)
+	assert.Equal(t, strings.ToLower(compoundV3Markets[ChainIDPolygon][0]), txs[1].To)
+	assert.Equal(t, "0xf3fef3a3"+word(strings.ToLower(testUSDC[2:]))+word("5"), txs[1].Data)
+}
+
+func TestBuildPositionTxsERC4626(t *testing.T) {
+	txs, err := BuildEnterPositionTxs(PositionProtocolERC4626, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: testVault, Asset: testUSDC, Amount: big.NewInt(16), Owner: testOwner,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "0x095ea7b3"+word(testVault[2:])+word("10"), txs[0].Data)
+	assert.Equal(t, testVault, txs[1].To)
+	assert.Equal(t, "0x6e553f65"+word("10")+word(testOwner[2:]), txs[1].Data)
+
+	txs, err = BuildExitPositionTxs(PositionProtocolERC4626, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: testVault, Asset: testUSDC, Amount: big.NewInt(16), Owner: testOwner,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "0xb460af94"+word("10")+word(testOwner[2:])+word(testOwner[2:]), txs[0].Data)
+
+	// Withdrawing everything redeems the shares
+	txs, err = BuildExitPositionTxs(PositionProtocolERC4626, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: testVault, Asset: testUSDC, Shares: big.NewInt(32), Owner: testOwner,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "0xba087652"+word("20")+word(testOwner[2:])+word(testOwner[2:]), txs[0].Data)
+
+	// which must be known
+	_, err = BuildExitPositionTxs(PositionProtocolERC4626, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: testVault, Asset: testUSDC, Owner: testOwner,
+	})
+	assert.Error(t, err)
+}
+
+func TestBuildClaimPositionTxs(t *testing.T) {
+	txs, err := BuildClaimPositionTxs(PositionProtocolCompoundV3, PositionTxRequest{
+		ChainID: ChainIDEthereum, Market: compoundV3Markets[ChainIDEthereum][0], Owner: testOwner,
+	})
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, strings.ToLower(compoundV3Rewards[ChainIDEthereum]), strings.ToLower(txs[0].To))
+	assert.Equal(t, word(testOwner[2:])+word("1"), txs[0].Data[10+64:])
+
+	// Vaults have nothing to claim
+	_, err = BuildClaimPositionTxs(PositionProtocolERC4626, PositionTxRequest{ChainID: ChainIDEthereum, Owner: testOwner})
+	assert.Error(t, err)
+}
+
+func TestBuildPositionTxsValidation(t *testing.T) {
+	valid := PositionTxRequest{ChainID: ChainIDEthereum, Asset: testUSDC, Amount: big.NewInt(1), Owner: testOwner}
+
+	_, err := BuildEnterPositionTxs("curve", valid)
+	assert.Error(t, err)
+	assert.False(t, SupportsPositionTxs("curve"))
+
+	noAmount := valid
+	noAmount.Amount = nil
+	_, err = BuildEnterPositionTxs(PositionProtocolAaveV3, noAmount)
+	assert.Error(t, err)
+
+	badOwner := valid
+	badOwner.Owner = "0x123"
+	_, err = BuildEnterPositionTxs(PositionProtocolAaveV3, badOwner)
+	assert.Error(t, err)
+
+	otherChain := valid
+	otherChain.ChainID = 56
+	_, err = BuildEnterPositionTxs(PositionProtocolAaveV3, otherChain)
+	assert.Error(t, err)
+}
+
+// word left-pads a hex value to one 32-byte ABI word
+func word(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
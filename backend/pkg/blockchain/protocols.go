@@ -0,0 +1,81 @@
+package blockchain
+
+import (
+	"sort"
+	"strings"
+)
+
+// Protocols recognised by the contracts a wallet deals with. Slugs match the protocols
+// table where the protocol is listed there.
+const (
+	ProtocolUniswap    = "uniswap"
+	ProtocolAaveV3     = "aave-v3"
+	ProtocolCompoundV3 = "compound-v3"
+	ProtocolLido       = "lido"
+	ProtocolOpenSea    = "opensea"
+	ProtocolBlur       = "blur"
+)
+
+var protocolNames = map[string]string{
+	ProtocolUniswap:    "Uniswap",
+	ProtocolAaveV3:     "Aave V3",
+	ProtocolCompoundV3: "Compound V3",
+	ProtocolLido:       "Lido",
+	ProtocolOpenSea:    "OpenSea",
+	ProtocolBlur:       "Blur",
+}
+
+// knownContract is a protocol's contract and the wallet label dealing with it suggests
+type knownContract struct {
+	protocol string
+	label    string
+}
+
+// knownContracts maps lowercase contract addresses to the protocol they belong to. The
+// addresses are the same on every chain they're deployed to.
+var knownContracts = map[string]knownContract{
+	"0xc36442b4a4522e871399cd717abdd847ab11fe88": {ProtocolUniswap, LabelUniswapLP},     // Uniswap v3 NonfungiblePositionManager
+	"0x7a250d5630b4cf539739df2c5dacb4c659f2488d": {ProtocolUniswap, LabelUniswapTrader}, // Uniswap v2 Router02
+	"0x68b3465833fb72a70ecdf485e0e4c7bd8665fc45": {ProtocolUniswap, LabelUniswapTrader}, // Uniswap SwapRouter02
+	"0x3fc91a3afd70395cd496c647d5a6cc9d4b2b7fad": {ProtocolUniswap, LabelUniswapTrader}, // Uniswap Universal Router
+	"0x00000000000000adc04c56bf30ac9d3c0aaf14dc": {ProtocolOpenSea, LabelNFTTrader},     // Seaport 1.5
+	"0x0000000000000068f116a894984e2db1123eb395": {ProtocolOpenSea, LabelNFTTrader},     // Seaport 1.6
+	"0x000000000000ad05ccc4f10045630fb830b95127": {ProtocolBlur, LabelNFTTrader},        // Blur marketplace
+	"0xae7ab96520de3a18e5e111b5eaab095312d7fe84": {ProtocolLido, LabelLidoStaker},       // Lido stETH
+}
+
+func init() {
+	for _, pool := range aaveV3Pools {
+		knownContracts[strings.ToLower(pool)] = knownContract{ProtocolAaveV3, LabelAaveUser}
+	}
+	for _, markets := range compoundV3Markets {
+		for _, market := range markets {
+			knownContracts[strings.ToLower(market)] = knownContract{ProtocolCompoundV3, LabelCompoundUser}
+		}
+	}
+}
+
+// ProtocolName returns the display name of the protocol with the slug, or the slug
+// itself when it isn't known
+func ProtocolName(protocol string) string {
+	if name, ok := protocolNames[protocol]; ok {
+		return name
+	}
+	return protocol
+}
+
+// ProtocolContracts returns the known protocol contracts as parallel slices of lowercase
+// addresses and protocol slugs, ordered by address
+func ProtocolContracts() ([]string, []string) {
+	addresses := make([]string, 0, len(knownContracts))
+	for address := range knownContracts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	protocols := make([]string, len(addresses))
+	for i, address := range addresses {
+		protocols[i] = knownContracts[address].protocol
+	}
+	return addresses, protocols
+}
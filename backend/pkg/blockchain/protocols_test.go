@@ -0,0 +1,30 @@
+package blockchain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolContracts(t *testing.T) {
+	addresses, protocols := ProtocolContracts()
+	require.Len(t, protocols, len(addresses))
+
+	byAddress := make(map[string]string)
+	for i, address := range addresses {
+		assert.Equal(t, strings.ToLower(address), address)
+		byAddress[address] = protocols[i]
+	}
+	assert.Equal(t, ProtocolUniswap, byAddress["0xc36442b4a4522e871399cd717abdd847ab11fe88"])
+	assert.Equal(t, ProtocolAaveV3, byAddress["0x87870bca3f3fd6335c3f4ce8392d69350b4fa4e2"])
+	assert.Equal(t, ProtocolCompoundV3, byAddress["0xc3d688b66703497daa19211eedff47f25384cdc3"])
+	assert.Equal(t, ProtocolLido, byAddress["0xae7ab96520de3a18e5e111b5eaab095312d7fe84"])
+	assert.Equal(t, ProtocolBlur, byAddress["0x000000000000ad05ccc4f10045630fb830b95127"])
+}
+
+func TestProtocolName(t *testing.T) {
+	assert.Equal(t, "Aave V3", ProtocolName(ProtocolAaveV3))
+	assert.Equal(t, "unknown-dex", ProtocolName("unknown-dex"))
+}
@@ -0,0 +1,156 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	selGetReserveData    = methodID("getReserveData(address)")
+	selGetRewardsByAsset = methodID("getRewardsByAsset(address)")
+	selGetRewardsData    = methodID("getRewardsData(address,address)")
+)
+
+const (
+	secondsPerDay  = 24 * 60 * 60
+	secondsPerYear = 365 * secondsPerDay
+	// reserveDataAToken is the aToken's word in the ReserveData getReserveData returns
+	reserveDataAToken = 8
+)
+
+// SupportsAaveV3Rewards reports whether Aave v3 incentives can be read on the chain
+func SupportsAaveV3Rewards(chainID int) bool {
+	_, pool := aaveV3Pools[chainID]
+	_, controller := aaveV3RewardsControllers[chainID]
+	return pool && controller
+}
+
+// GetAaveV3RewardEmissions reads the incentives paid to suppliers of an Aave v3 reserve:
+// each reward token's daily emission, when its distribution ends and, where the reward
+// and the asset are priced, the APR it adds. Finished distributions are left out.
+func (s *BlockchainService) GetAaveV3RewardEmissions(ctx context.Context, chainID int, asset string) ([]models.RewardAPR, error) {
+	if !SupportsAaveV3Rewards(chainID) {
+		return nil, fmt.Errorf("aave v3 rewards are not supported on chain %d", chainID)
+	}
+	if !common.IsHexAddress(asset) {
+		return nil, fmt.Errorf("invalid asset address: %q", asset)
+	}
+	pool, controller := aaveV3Pools[chainID], aaveV3RewardsControllers[chainID]
+
+	results, err := s.alchemyClient.multicall(ctx, chainID, []Call{newCall(pool, selGetReserveData, encodeAddress(asset))})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 reserve: %w", err)
+	}
+	if !results[0].Success || len(results[0].ReturnData) < (reserveDataAToken+1)*32 {
+		return nil, fmt.Errorf("%s is not an aave v3 reserve", asset)
+	}
+	aToken := decodeAddress(results[0].ReturnData, reserveDataAToken).Hex()
+
+	if results, err = s.alchemyClient.multicall(ctx, chainID, []Call{
+		newCall(controller, selGetRewardsByAsset, encodeAddress(aToken)),
+		newCall(aToken, selTotalSupply),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 rewards: %w", err)
+	}
+	if !results[0].Success {
+		return nil, fmt.Errorf("failed to read aave v3 rewards of %s", aToken)
+	}
+	rewardTokens := decodeAddressArray(results[0].ReturnData)
+	supply := uintResult(results[1])
+	if len(rewardTokens) == 0 {
+		return nil, nil
+	}
+
+	// getRewardsData returns (index, emissionPerSecond, lastUpdateTimestamp, distributionEnd)
+	calls := make([]Call, len(rewardTokens))
+	for i, reward := range rewardTokens {
+		calls[i] = newCall(controller, selGetRewardsData, encodeAddress(aToken), encodeAddress(reward))
+	}
+	if results, err = s.alchemyClient.multicall(ctx, chainID, calls); err != nil {
+		return nil, fmt.Errorf("failed to read aave v3 emissions: %w", err)
+	}
+
+	metadata, err := s.alchemyClient.getTokenMetadata(ctx, append([]string{asset}, rewardTokens...), chainID)
+	if err != nil {
+		return nil, err
+	}
+	assetMeta, ok := metadata[strings.ToLower(asset)]
+	if !ok {
+		return nil, fmt.Errorf("no metadata for aave v3 asset %s", asset)
+	}
+	symbols := []string{assetMeta.Symbol}
+	for _, meta := range metadata {
+		symbols = append(symbols, meta.Symbol)
+	}
+	prices, err := s.GetSymbolPricesUSD(ctx, symbols)
+	if err != nil {
+		// Emission rates and end dates are still worth returning unpriced
+		prices = nil
+	}
+
+	now := time.Now()
+	var rewards []models.RewardAPR
+	for i, token := range rewardTokens {
+		meta, ok := metadata[strings.ToLower(token)]
+		if !ok || !results[i].Success || len(results[i].ReturnData) < 4*32 {
+			continue
+		}
+		emission := decodeUint(results[i].ReturnData, 1)
+		end := decodeUint(results[i].ReturnData, 3)
+		if emission.Sign() == 0 || !end.IsInt64() || end.Int64() <= now.Unix() {
+			continue
+		}
+
+		endsAt := time.Unix(end.Int64(), 0).UTC()
+		perDay := scaleDown(emission, meta.Decimals) * secondsPerDay
+		reward := models.RewardAPR{
+			Token:          strings.ToLower(token),
+			Symbol:         meta.Symbol,
+			EmissionPerDay: &perDay,
+			EndsAt:         &endsAt,
+			Source:         models.RewardSourceOnchain,
+		}
+		rewardPrice, rewardPriced := prices[meta.Symbol]
+		assetPrice, assetPriced := prices[assetMeta.Symbol]
+		if rewardPriced && assetPriced && supply != nil {
+			reward.APR = emissionAPR(emission, meta.Decimals, rewardPrice, supply, assetMeta.Decimals, assetPrice)
+		}
+		rewards = append(rewards, reward)
+	}
+	return rewards, nil
+}
+
+// emissionAPR is the yearly value of an emission as a percentage of the value it's shared
+// by, or nil when nothing is deposited
+func emissionAPR(emissionPerSecond *big.Int, rewardDecimals int, rewardPrice float64, supply *big.Int, supplyDecimals int, supplyPrice float64) *float64 {
+	deposited := scaleDown(supply, supplyDecimals) * supplyPrice
+	if deposited <= 0 {
+		return nil
+	}
+	apr := scaleDown(emissionPerSecond, rewardDecimals) * secondsPerYear * rewardPrice / deposited * 100
+	return &apr
+}
+
+// decodeAddressArray decodes a result that is a single address[]
+func decodeAddressArray(data []byte) []string {
+	offset := decodeUint(data, 0)
+	if !offset.IsInt64() || offset.Int64()%32 != 0 {
+		return nil
+	}
+	start := int(offset.Int64() / 32)
+	length := decodeUint(data, start)
+	if !length.IsInt64() || len(data) < (start+1+int(length.Int64()))*32 {
+		return nil
+	}
+	addresses := make([]string, length.Int64())
+	for i := range addresses {
+		addresses[i] = decodeAddress(data, start+1+i).Hex()
+	}
+	return addresses
+}
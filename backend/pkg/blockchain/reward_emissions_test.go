@@ -0,0 +1,33 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmissionAPR(t *testing.T) {
+	// 1 token/s at $0.5 over $100M supplied: 31,536,000 * 0.5 / 1e8 = 15.768%
+	emission := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	supply := new(big.Int).Mul(big.NewInt(100_000_000), big.NewInt(1_000_000))
+	apr := emissionAPR(emission, 18, 0.5, supply, 6, 1)
+	require.NotNil(t, apr)
+	assert.InDelta(t, 15.768, *apr, 1e-9)
+
+	assert.Nil(t, emissionAPR(emission, 18, 0.5, new(big.Int), 6, 1))
+}
+
+func TestDecodeAddressArray(t *testing.T) {
+	a := "0x1111111111111111111111111111111111111111"
+	b := "0x2222222222222222222222222222222222222222"
+	data := append(encodeUint(big.NewInt(32)), encodeUint(big.NewInt(2))...)
+	data = append(data, encodeAddress(a)...)
+	data = append(data, encodeAddress(b)...)
+
+	assert.Equal(t, []string{common.HexToAddress(a).Hex(), common.HexToAddress(b).Hex()}, decodeAddressArray(data))
+	// A length running past the data is rejected
+	assert.Nil(t, decodeAddressArray(data[:3*32]))
+}
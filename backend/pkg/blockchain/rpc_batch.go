@@ -0,0 +1,169 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	// DefaultRPCBatchSize is how many calls go in one JSON-RPC batch request unless
+	// configured otherwise
+	DefaultRPCBatchSize = 100
+	// MaxRPCBatchSize is the most calls Alchemy takes in one batch over HTTP
+	MaxRPCBatchSize = 1000
+)
+
+var (
+	rpcBatchMu sync.RWMutex
+	// rpcBatchSize bounds the calls sent in one batch; rpcBatchSizes overrides it per
+	// chain, e.g. for custom chains whose public endpoints take smaller batches
+	rpcBatchSize  = DefaultRPCBatchSize
+	rpcBatchSizes = map[int]int{}
+)
+
+// SetRPCBatchSizes sets how many calls go in one JSON-RPC batch, by default and per
+// chain. A size that isn't positive keeps DefaultRPCBatchSize, and sizes are capped at
+// MaxRPCBatchSize.
+func SetRPCBatchSizes(size int, byChain map[int]int) {
+	clamp := func(size int) int {
+		if size <= 0 {
+			return DefaultRPCBatchSize
+		}
+		if size > MaxRPCBatchSize {
+			return MaxRPCBatchSize
+		}
+		return size
+	}
+
+	sizes := make(map[int]int, len(byChain))
+	for chainID, chainSize := range byChain {
+		sizes[chainID] = clamp(chainSize)
+	}
+
+	rpcBatchMu.Lock()
+	defer rpcBatchMu.Unlock()
+	rpcBatchSize = clamp(size)
+	rpcBatchSizes = sizes
+}
+
+// RPCBatchSize returns how many calls go in one JSON-RPC batch on the chain
+func RPCBatchSize(chainID int) int {
+	rpcBatchMu.RLock()
+	defer rpcBatchMu.RUnlock()
+	if size, ok := rpcBatchSizes[chainID]; ok {
+		return size
+	}
+	return rpcBatchSize
+}
+
+// rpcRequest is one call of a JSON-RPC batch
+type rpcRequest struct {
+	Method string
+	Params []interface{}
+}
+
+// rpcResponse is the node's answer to one call of a batch
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("RPC error %d: %s", e.Code, e.Message)
+}
+
+// batchCall sends requests as JSON-RPC batches of at most the chain's RPCBatchSize calls
+// and returns the responses in request order. A call the node left unanswered gets an error
+// response, so a failed call never fails the others.
+func (c *AlchemyClient) batchCall(ctx context.Context, chainID int, requests []rpcRequest) ([]rpcResponse, error) {
+	baseURL, exists := c.baseURL(chainID)
+	if !exists {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	size := RPCBatchSize(chainID)
+	responses := make([]rpcResponse, len(requests))
+	for start := 0; start < len(requests); start += size {
+		end := start + size
+		if end > len(requests) {
+			end = len(requests)
+		}
+		if err := c.sendBatch(ctx, baseURL, start, requests[start:end], responses[start:end]); err != nil {
+			return nil, err
+		}
+	}
+	return responses, nil
+}
+
+// sendBatch sends one batch, numbering calls from offset, and fills responses by id
+func (c *AlchemyClient) sendBatch(ctx context.Context, baseURL string, offset int, requests []rpcRequest, responses []rpcResponse) error {
+	body := make([]map[string]interface{}, len(requests))
+	for i, request := range requests {
+		params := request.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+		body[i] = map[string]interface{}{
+			"id":      offset + i,
+			"jsonrpc": "2.0",
+			"method":  request.Method,
+			"params":  params,
+		}
+	}
+
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewReader(reqBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// A rejected batch gets a single error response, or none when throttled
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read batch response: %w", err)
+	}
+	var batch []rpcResponse
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		var single rpcResponse
+		if json.Unmarshal(raw, &single) == nil && single.Error != nil {
+			return alchemyError(resp.StatusCode, single.Error.Code, single.Error.Message)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return alchemyError(resp.StatusCode, 0, resp.Status)
+		}
+		return fmt.Errorf("failed to decode batch response: %w", err)
+	}
+
+	for i := range responses {
+		responses[i] = rpcResponse{ID: offset + i, Error: &rpcError{Code: -32603, Message: "no response in batch"}}
+	}
+	for _, response := range batch {
+		if i := response.ID - offset; i >= 0 && i < len(responses) {
+			responses[i] = response
+		}
+	}
+	return nil
+}
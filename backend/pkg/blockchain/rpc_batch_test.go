@@ -0,0 +1,124 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcTestServer answers each call of a batch with answer, counting the batches and the
+// largest one
+func rpcTestServer(t *testing.T, answer func(method string, params []json.RawMessage) map[string]interface{}) (*AlchemyClient, *int, *int) {
+	var batches, largest int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batches++
+		var calls []struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&calls))
+		if len(calls) > largest {
+			largest = len(calls)
+		}
+
+		responses := make([]map[string]interface{}, 0, len(calls))
+		for _, call := range calls {
+			response := answer(call.Method, call.Params)
+			response["jsonrpc"], response["id"] = "2.0", call.ID
+			responses = append(responses, response)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(responses))
+	}))
+	t.Cleanup(server.Close)
+	return &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL, 10: server.URL}}, &batches, &largest
+}
+
+func TestBatchCallSizes(t *testing.T) {
+	client, batches, largest := rpcTestServer(t, func(string, []json.RawMessage) map[string]interface{} {
+		return map[string]interface{}{"result": "0x1"}
+	})
+	defer SetRPCBatchSizes(0, nil)
+
+	requests := make([]rpcRequest, 5)
+	for i := range requests {
+		requests[i] = rpcRequest{Method: "eth_blockNumber"}
+	}
+
+	SetRPCBatchSizes(2, map[int]int{10: 5000})
+	assert.Equal(t, 2, RPCBatchSize(1))
+	assert.Equal(t, MaxRPCBatchSize, RPCBatchSize(10))
+
+	responses, err := client.batchCall(context.Background(), 1, requests)
+	require.NoError(t, err)
+	assert.Len(t, responses, 5)
+	assert.Equal(t, 3, *batches)
+	assert.Equal(t, 2, *largest)
+
+	*batches = 0
+	_, err = client.batchCall(context.Background(), 10, requests)
+	require.NoError(t, err)
+	assert.Equal(t, 1, *batches)
+
+	SetRPCBatchSizes(0, nil)
+	assert.Equal(t, DefaultRPCBatchSize, RPCBatchSize(10))
+}
+
+func TestBatchCallRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":429,"message":"Your app has exceeded its compute units per second capacity"}}`))
+	}))
+	defer server.Close()
+
+	client := &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}
+	_, err := client.batchCall(context.Background(), 1, []rpcRequest{{Method: "eth_blockNumber"}})
+	assert.ErrorIs(t, err, ErrRateLimited)
+}
+
+func TestGetWalletHoldings(t *testing.T) {
+	const usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	nativeFails := false
+	client, batches, _ := rpcTestServer(t, func(method string, params []json.RawMessage) map[string]interface{} {
+		switch method {
+		case "eth_getBalance":
+			if nativeFails {
+				return map[string]interface{}{"error": map[string]interface{}{"code": 429, "message": "rate limited"}}
+			}
+			return map[string]interface{}{"result": "0xde0b6b3a7640000"}
+		case "alchemy_getTokenBalances":
+			return map[string]interface{}{"result": map[string]interface{}{
+				"address": testWallet,
+				"tokenBalances": []map[string]string{
+					{"contractAddress": usdc, "tokenBalance": "0x9502f900"},
+					{"contractAddress": "0x1111111111111111111111111111111111111111", "tokenBalance": "0x0"},
+				},
+			}}
+		case "alchemy_getTokenMetadata":
+			return map[string]interface{}{"result": map[string]interface{}{"decimals": 6, "name": "USD Coin", "symbol": "USDC"}}
+		}
+		return map[string]interface{}{"error": map[string]interface{}{"code": -32601, "message": "method not found"}}
+	})
+
+	holdings, err := client.getWalletHoldings(context.Background(), testWallet, 1)
+	require.NoError(t, err)
+	require.NoError(t, holdings.nativeErr)
+	assert.Equal(t, big.NewInt(1e18), holdings.native)
+	require.Len(t, holdings.tokens, 1)
+	assert.Equal(t, "USDC", holdings.tokens[0].Token.Symbol)
+	assert.Equal(t, "2500000000", holdings.tokens[0].Balance)
+	assert.Equal(t, 2, *batches, "balances in one batch, then metadata")
+
+	// A failed native read keeps the token balances
+	nativeFails = true
+	holdings, err = client.getWalletHoldings(context.Background(), testWallet, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, holdings.nativeErr, ErrRateLimited)
+	assert.Len(t, holdings.tokens, 1)
+}
\ No newline at end of file
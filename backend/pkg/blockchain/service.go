@@ -3,15 +3,15 @@ package blockchain
 import (
 	"context"
 	"fmt"
-	"math/big"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+	"github.com/defi-dashboard/backend/pkg/decimal"
 	"github.com/defi-dashboard/backend/pkg/external"
 	"github.com/defi-dashboard/backend/pkg/logger"
-	"github.com/google/uuid"
 )
 
 type BlockchainService struct {
@@ -26,9 +26,40 @@ func NewBlockchainService(alchemyAPIKey, coinGeckoAPIKey string) *BlockchainServ
 	}
 }
 
+// platformKeys are the server-side keys used when a request brings none. They
+// are seeded from the environment and updated by the secrets manager on rotation.
+var platformKeys struct {
+	sync.RWMutex
+	alchemy   string
+	coinGecko string
+}
+
+// SetPlatformAlchemyKey overrides the fallback Alchemy key for dynamically created services
+func SetPlatformAlchemyKey(key string) {
+	platformKeys.Lock()
+	defer platformKeys.Unlock()
+	platformKeys.alchemy = key
+}
+
+// SetPlatformCoinGeckoKey overrides the fallback CoinGecko key for dynamically created services
+func SetPlatformCoinGeckoKey(key string) {
+	platformKeys.Lock()
+	defer platformKeys.Unlock()
+	platformKeys.coinGecko = key
+}
+
 // NewBlockchainServiceWithDynamicKeys creates a blockchain service with runtime API keys
 func NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, coinGeckoAPIKey string) *BlockchainService {
-	// Use fallback keys from environment if headers are empty
+	// Use fallback platform keys (secrets manager, then environment) if headers are empty
+	platformKeys.RLock()
+	if alchemyAPIKey == "" {
+		alchemyAPIKey = platformKeys.alchemy
+	}
+	if coinGeckoAPIKey == "" {
+		coinGeckoAPIKey = platformKeys.coinGecko
+	}
+	platformKeys.RUnlock()
+
 	if alchemyAPIKey == "" {
 		alchemyAPIKey = os.Getenv("ALCHEMY_API_KEY")
 	}
@@ -43,35 +74,26 @@ func NewBlockchainServiceWithDynamicKeys(alchemyAPIKey, coinGeckoAPIKey string)
 	return NewBlockchainService(alchemyAPIKey, coinGeckoAPIKey)
 }
 
+// SetAlchemyAPIKey rotates the Alchemy key of a long-lived service
+func (s *BlockchainService) SetAlchemyAPIKey(key string) {
+	s.alchemyClient.SetAPIKey(key)
+}
+
+// SetCoinGeckoAPIKey rotates the CoinGecko key of a long-lived service
+func (s *BlockchainService) SetCoinGeckoAPIKey(key string) {
+	s.coinGeckoClient.SetAPIKey(key)
+}
+
 // GetWalletBalances fetches complete wallet balances with USD values
 func (s *BlockchainService) GetWalletBalances(ctx context.Context, address string, chainID int) ([]*models.Balance, float64, error) {
 	logger.Info("Fetching wallet balances", "address", address, "chainID", chainID)
 
-	// Get token balances
+	// The native balance is listed among the tokens, on every chain
 	balances, err := s.alchemyClient.GetTokenBalances(ctx, address, chainID)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get token balances: %w", err)
 	}
 
-	// Get ETH balance (skip for Polygon Amoy as it's already included in GetTokenBalances)
-	if chainID != 80002 {
-		ethBalance, err := s.alchemyClient.GetETHBalance(ctx, address, chainID)
-		if err != nil {
-			logger.Error("Failed to get ETH balance", "error", err)
-		} else if ethBalance.Cmp(big.NewInt(0)) > 0 {
-			// Add ETH balance to results
-			ethToken := s.createETHToken(chainID)
-			balance := &models.Balance{
-				ID:       uuid.New(),
-				WalletID: uuid.New(),
-				TokenID:  ethToken.ID,
-				Token:    ethToken,
-				Balance:  ethBalance.String(),
-			}
-			balances = append(balances, balance)
-		}
-	}
-
 	// Get USD prices for all tokens
 	totalValue, err := s.enrichBalancesWithPrices(ctx, balances)
 	if err != nil {
@@ -87,6 +109,75 @@ func (s *BlockchainService) GetWalletBalances(ctx context.Context, address strin
 	return balances, totalValue, nil
 }
 
+// GetWalletBalancesAtHead fetches wallet balances to be stored, stamping each with the
+// number and hash of the chain head read just before, so that a reorg of that block is
+// noticed and the balances read again. Balances are left unstamped if the head can't be read.
+func (s *BlockchainService) GetWalletBalancesAtHead(ctx context.Context, address string, chainID int) ([]*models.Balance, float64, error) {
+	head, err := s.GetBlock(ctx, chainID, nil)
+	if err != nil {
+		logger.Warn("Failed to fetch chain head", "chainID", chainID, "error", err)
+	}
+
+	balances, totalValue, err := s.GetWalletBalances(ctx, address, chainID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if head != nil {
+		for _, balance := range balances {
+			number, hash := head.Number, head.Hash
+			balance.BlockNumber, balance.BlockHash = &number, &hash
+		}
+	}
+	return balances, totalValue, nil
+}
+
+// GetWalletBalancesAtBlock fetches wallet balances as of a single block, so that every
+// balance on the chain is consistent with the others. The token indexer only knows
+// current holdings, so those tokens and the native balance are re-read at the block;
+// tokens held then but since emptied aren't found. Values use current prices.
+func (s *BlockchainService) GetWalletBalancesAtBlock(ctx context.Context, address string, chainID int, block int64) ([]*models.Balance, float64, error) {
+	logger.Info("Fetching wallet balances at block", "address", address, "chainID", chainID, "block", block)
+
+	current, err := s.alchemyClient.GetTokenBalances(ctx, address, chainID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get token balances: %w", err)
+	}
+
+	var tokens []string
+	held := make([]*models.Balance, 0, len(current))
+	for _, balance := range current {
+		if balance.Token == nil || balance.Token.IsNative() {
+			continue
+		}
+		tokens = append(tokens, balance.Token.Address)
+		held = append(held, balance)
+	}
+
+	read, err := s.alchemyClient.erc20BalancesAt(ctx, chainID, address, tokens, &block)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read balances at block %d: %w", block, err)
+	}
+
+	var balances []*models.Balance
+	if native, ok := read[NativeTokenAddress]; ok && native.Sign() > 0 {
+		balances = append(balances, nativeBalance(chainID, native))
+	}
+	for _, balance := range held {
+		amount, ok := read[balance.Token.Address]
+		if !ok || amount.Sign() == 0 {
+			continue
+		}
+		balance.Balance = amount.String()
+		balances = append(balances, balance)
+	}
+
+	totalValue, err := s.enrichBalancesWithPrices(ctx, balances)
+	if err != nil {
+		logger.Error("Failed to enrich balances with prices", "error", err)
+	}
+	return balances, totalValue, nil
+}
+
 // enrichBalancesWithPrices adds USD price data to balances
 func (s *BlockchainService) enrichBalancesWithPrices(ctx context.Context, balances []*models.Balance) (float64, error) {
 	if len(balances) == 0 {
@@ -99,8 +190,7 @@ func (s *BlockchainService) enrichBalancesWithPrices(ctx context.Context, balanc
 	
 	for _, balance := range balances {
 		if balance.Token != nil {
-			symbol := strings.ToLower(balance.Token.Symbol)
-			if coingeckoID, exists := external.TokenIDMappings[symbol]; exists {
+			if coingeckoID, exists := tokenCoinGeckoID(balance.Token); exists {
 				tokenIDs = append(tokenIDs, coingeckoID)
 				symbolToToken[coingeckoID] = balance.Token
 			}
@@ -124,8 +214,7 @@ func (s *BlockchainService) enrichBalancesWithPrices(ctx context.Context, balanc
 			continue
 		}
 
-		symbol := strings.ToLower(balance.Token.Symbol)
-		coingeckoID, exists := external.TokenIDMappings[symbol]
+		coingeckoID, exists := tokenCoinGeckoID(balance.Token)
 		if !exists {
 			continue
 		}
@@ -142,19 +231,18 @@ func (s *BlockchainService) enrichBalancesWithPrices(ctx context.Context, balanc
 
 		// Calculate balance USD value
 		if balance.Token.Decimals > 0 {
-			// Convert from wei to decimal
-			balanceInt, ok := new(big.Int).SetString(balance.Balance, 10)
-			if !ok {
+			raw, err := amounts.ParseBaseUnits(balance.Balance)
+			if err != nil {
 				logger.Error("Failed to parse balance", "balance", balance.Balance)
 				continue
 			}
+			value, err := amounts.USDValue(raw, balance.Token.Decimals, priceData.USD)
+			if err != nil {
+				logger.Error("Failed to value balance", "balance", balance.Balance, "error", err)
+				continue
+			}
 
-			divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(balance.Token.Decimals)), nil))
-			balanceFloat := new(big.Float).SetInt(balanceInt)
-			balanceFloat.Quo(balanceFloat, divisor)
-
-			decimalBalance, _ := balanceFloat.Float64()
-			usdValue := decimalBalance * priceData.USD
+			usdValue := value.Float64()
 			balance.BalanceUSD = &usdValue
 			totalValue += usdValue
 		}
@@ -163,39 +251,52 @@ func (s *BlockchainService) enrichBalancesWithPrices(ctx context.Context, balanc
 	return totalValue, nil
 }
 
-// createETHToken creates an ETH token model for the given chain
-func (s *BlockchainService) createETHToken(chainID int) *models.Token {
-	var symbol, name string
-	
-	switch chainID {
-	case 1: // Ethereum
-		symbol = "ETH"
-		name = "Ether"
-	case 137: // Polygon
-		symbol = "MATIC"
-		name = "Polygon"
-	case 42161: // Arbitrum
-		symbol = "ETH"
-		name = "Ether"
-	case 10: // Optimism
-		symbol = "ETH" 
-		name = "Ether"
-	case 80002: // Polygon Amoy
-		symbol = "MATIC"
-		name = "Polygon"
-	default:
-		symbol = "ETH"
-		name = "Ether"
+// tokenCoinGeckoID returns the CoinGecko ID a token is priced by: its chain's gas token
+// ID for a native token, and otherwise the ID of its symbol
+func tokenCoinGeckoID(token *models.Token) (string, bool) {
+	if strings.EqualFold(token.Address, NativeTokenAddress) {
+		if id, ok := nativeTokenCoinGeckoIDs[token.ChainID]; ok {
+			return id, true
+		}
+		if chain, ok := LookupCustomChain(token.ChainID); ok && chain.NativeCoinGeckoID != nil {
+			return *chain.NativeCoinGeckoID, true
+		}
+	}
+	id, ok := external.TokenIDMappings[strings.ToLower(token.Symbol)]
+	return id, ok
+}
+
+// GetSymbolPricesUSD returns current USD prices keyed by the given symbols. Symbols
+// without a known CoinGecko ID are omitted.
+func (s *BlockchainService) GetSymbolPricesUSD(ctx context.Context, symbols []string) (map[string]float64, error) {
+	idsBySymbol := make(map[string]string)
+	ids := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		id, ok := external.TokenIDMappings[strings.ToLower(symbol)]
+		if !ok {
+			continue
+		}
+		if _, seen := idsBySymbol[symbol]; !seen {
+			ids = append(ids, id)
+		}
+		idsBySymbol[symbol] = id
+	}
+
+	result := make(map[string]float64, len(idsBySymbol))
+	if len(ids) == 0 {
+		return result, nil
 	}
 
-	return &models.Token{
-		ID:       uuid.New(),
-		Address:  "0x0000000000000000000000000000000000000000", // Native token
-		ChainID:  chainID,
-		Symbol:   symbol,
-		Name:     name,
-		Decimals: 18,
+	prices, err := s.coinGeckoClient.GetTokenPrices(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token prices: %w", err)
+	}
+	for symbol, id := range idsBySymbol {
+		if price, ok := prices[id]; ok {
+			result[symbol] = price.USD
+		}
 	}
+	return result, nil
 }
 
 // GetTransactionHistory fetches transaction history for an address
@@ -219,44 +320,33 @@ func (s *BlockchainService) GetTransactionHistory(ctx context.Context, address s
 	return transactions, nil
 }
 
+// GetTransactionsInRange fetches every transaction sent or received by an address
+// within a block range, oldest first, for incremental syncs
+func (s *BlockchainService) GetTransactionsInRange(ctx context.Context, address string, chainID int, blocks BlockRange) ([]*models.Transaction, error) {
+	transactions, err := s.alchemyClient.GetTransactionsInRange(ctx, address, chainID, blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	s.fillTransactionBlockHashes(ctx, chainID, transactions)
+	return transactions, nil
+}
+
 // ParseTokenAmount converts a token amount string to a float64 based on decimals
 func ParseTokenAmount(amount string, decimals int) (float64, error) {
 	if amount == "" || amount == "0" {
 		return 0, nil
 	}
 
-	// Parse as big integer
-	amountInt, ok := new(big.Int).SetString(amount, 10)
-	if !ok {
+	raw, err := amounts.ParseBaseUnits(amount)
+	if err != nil {
 		return 0, fmt.Errorf("invalid amount: %s", amount)
 	}
-
-	// Convert to decimal
-	divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
-	amountFloat := new(big.Float).SetInt(amountInt)
-	amountFloat.Quo(amountFloat, divisor)
-
-	result, _ := amountFloat.Float64()
-	return result, nil
+	return amounts.Float64(raw, decimals), nil
 }
 
 // FormatTokenAmount formats a token amount for display
 func FormatTokenAmount(amount float64, decimals int) string {
-	if amount == 0 {
-		return "0"
-	}
-
-	// Determine appropriate precision
-	var precision int
-	if amount >= 1000 {
-		precision = 2
-	} else if amount >= 1 {
-		precision = 4
-	} else {
-		precision = 6
-	}
-
-	return strconv.FormatFloat(amount, 'f', precision, 64)
+	return amounts.Display(decimal.NewFromFloat(amount))
 }
 
 // Chain ID constants
@@ -282,11 +372,15 @@ func GetChainName(chainID int) string {
 	case ChainIDPolygonAmoy:
 		return "Polygon Amoy"
 	default:
+		if chain, ok := LookupCustomChain(chainID); ok {
+			return chain.Name
+		}
 		return fmt.Sprintf("Chain %d", chainID)
 	}
 }
 
-// GetSupportedChains returns list of supported chain IDs
+// GetSupportedChains returns list of supported chain IDs, built-in chains first and
+// then registered custom chains
 func GetSupportedChains() []int {
-	return []int{ChainIDEthereum, ChainIDPolygon, ChainIDArbitrum, ChainIDOptimism, ChainIDPolygonAmoy}
+	return append(append([]int{}, builtinChains...), customChainIDs()...)
 }
\ No newline at end of file
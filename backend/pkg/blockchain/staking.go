@@ -0,0 +1,174 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// liquidStakingToken describes how to read a liquid staking token's holdings and its
+// ETH exchange rate on-chain
+type liquidStakingToken struct {
+	address  string
+	symbol   string
+	protocol string
+	// unitsSelector reads the holder's non-rebasing units; rateSelector (with rateArgs)
+	// returns the ETH value of one whole unit with 18 decimals
+	unitsSelector []byte
+	rateSelector  []byte
+	rateArgs      [][]byte
+	// rebasing tokens show units * rate as their balance
+	rebasing bool
+}
+
+var (
+	selBalanceOf            = methodID("balanceOf(address)")
+	selSharesOf             = methodID("sharesOf(address)")
+	selGetPooledEthByShares = methodID("getPooledEthByShares(uint256)")
+	selStEthPerToken        = methodID("stEthPerToken()")
+	selGetExchangeRate      = methodID("getExchangeRate()")
+	selExchangeRate         = methodID("exchangeRate()")
+)
+
+var oneEther = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// liquidStakingTokens are the ETH liquid staking tokens per chain. Bridged copies on L2s
+// don't expose the exchange rate, so only mainnet is covered.
+var liquidStakingTokens = map[int][]liquidStakingToken{
+	ChainIDEthereum: {
+		{
+			address:       "0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84",
+			symbol:        "stETH",
+			protocol:      models.StakingProtocolLido,
+			unitsSelector: selSharesOf,
+			rateSelector:  selGetPooledEthByShares,
+			rateArgs:      [][]byte{encodeUint(oneEther)},
+			rebasing:      true,
+		},
+		{
+			address:       "0x7f39C581F595B53c5cb19bD0b3f8dA6c935E2Ca0",
+			symbol:        "wstETH",
+			protocol:      models.StakingProtocolLido,
+			unitsSelector: selBalanceOf,
+			rateSelector:  selStEthPerToken,
+		},
+		{
+			address:       "0xae78736Cd615f374D3085123A210448E74Fc6393",
+			symbol:        "rETH",
+			protocol:      models.StakingProtocolRocketPool,
+			unitsSelector: selBalanceOf,
+			rateSelector:  selGetExchangeRate,
+		},
+		{
+			address:       "0xBe9895146f7AF43049ca1c1AE358B0541Ea49704",
+			symbol:        "cbETH",
+			protocol:      models.StakingProtocolCoinbase,
+			unitsSelector: selBalanceOf,
+			rateSelector:  selExchangeRate,
+		},
+	},
+}
+
+// SupportsLiquidStaking reports whether liquid staking tokens are tracked on the chain
+func SupportsLiquidStaking(chainID int) bool {
+	_, ok := liquidStakingTokens[chainID]
+	return ok
+}
+
+// GetLiquidStakingPositions reads a wallet's stETH, wstETH, rETH and cbETH holdings with
+// their current ETH exchange rates. Tokens the wallet doesn't hold are omitted; principal
+// and accrued yield are left for the caller, which knows the holding's history. Unlike
+// debt positions a partial read is an error, as an omitted token means an exited holding.
+func (s *BlockchainService) GetLiquidStakingPositions(ctx context.Context, address string, chainID int) ([]*models.LiquidStakingPosition, error) {
+	tokens, ok := liquidStakingTokens[chainID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain ID: %d", chainID)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		positions []*models.LiquidStakingPosition
+		firstErr  error
+	)
+	for _, token := range tokens {
+		wg.Add(1)
+		go func(token liquidStakingToken) {
+			defer wg.Done()
+			position, err := s.alchemyClient.liquidStakingPosition(ctx, chainID, token, address)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if position != nil {
+				positions = append(positions, position)
+			}
+		}(token)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return positions, nil
+}
+
+func (c *AlchemyClient) liquidStakingPosition(ctx context.Context, chainID int, token liquidStakingToken, address string) (*models.LiquidStakingPosition, error) {
+	out, err := c.ethCall(ctx, chainID, token.address, token.unitsSelector, encodeAddress(address))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s balance: %w", token.symbol, err)
+	}
+	units := decodeUint(out, 0)
+	if units.Sign() == 0 {
+		return nil, nil
+	}
+
+	out, err = c.ethCall(ctx, chainID, token.address, token.rateSelector, token.rateArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s exchange rate: %w", token.symbol, err)
+	}
+
+	position := liquidStakingValue(scaleDown(units, 18), scaleDown(decodeUint(out, 0), 18), token.rebasing)
+	position.Protocol = token.protocol
+	position.ChainID = chainID
+	position.WalletAddress = address
+	position.Token = common.HexToAddress(token.address).Hex()
+	position.Symbol = token.symbol
+	return position, nil
+}
+
+// liquidStakingValue values units at an ETH exchange rate
+func liquidStakingValue(units, rate float64, rebasing bool) *models.LiquidStakingPosition {
+	position := &models.LiquidStakingPosition{
+		Units:         units,
+		ExchangeRate:  rate,
+		UnderlyingETH: units * rate,
+		Balance:       units,
+	}
+	if rebasing {
+		position.Balance = position.UnderlyingETH
+	}
+	return position
+}
+
+// GetETHPriceUSD returns the current ETH price from CoinGecko
+func (s *BlockchainService) GetETHPriceUSD(ctx context.Context) (float64, error) {
+	id := NativeTokenCoinGeckoID(ChainIDEthereum)
+	prices, err := s.coinGeckoClient.GetTokenPrices(ctx, []string{id})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ETH price: %w", err)
+	}
+	price, ok := prices[id]
+	if !ok {
+		return 0, fmt.Errorf("no ETH price returned")
+	}
+	return price.USD, nil
+}
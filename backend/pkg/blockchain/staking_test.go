@@ -0,0 +1,28 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiquidStakingSelectors(t *testing.T) {
+	assert.Equal(t, "f5eb42dc", hex.EncodeToString(selSharesOf))
+	assert.Equal(t, "7a28fb88", hex.EncodeToString(selGetPooledEthByShares))
+	assert.Equal(t, "035faf82", hex.EncodeToString(selStEthPerToken))
+	assert.Equal(t, "e6aa216c", hex.EncodeToString(selGetExchangeRate))
+	assert.Equal(t, "3ba0b9a9", hex.EncodeToString(selExchangeRate))
+}
+
+func TestLiquidStakingValue(t *testing.T) {
+	// rETH: the balance is the units, the ETH value grows with the rate
+	position := liquidStakingValue(10, 1.1, false)
+	assert.InDelta(t, 10.0, position.Balance, 1e-9)
+	assert.InDelta(t, 11.0, position.UnderlyingETH, 1e-9)
+
+	// stETH: units are shares and the rebasing balance is their pooled ETH
+	position = liquidStakingValue(10, 1.15, true)
+	assert.InDelta(t, 11.5, position.Balance, 1e-9)
+	assert.InDelta(t, 11.5, position.UnderlyingETH, 1e-9)
+}
@@ -0,0 +1,290 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/hexutil"
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// TokenMetadataStore caches token metadata between provider lookups. Tokens are keyed by
+// chain and lowercase address.
+type TokenMetadataStore interface {
+	GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error)
+	UpsertTokens(ctx context.Context, tokens []*models.Token) error
+}
+
+// metadataStore is shared by every client, including those created per request
+var metadataStore struct {
+	sync.RWMutex
+	store TokenMetadataStore
+}
+
+// SetTokenMetadataStore sets where token metadata fetched from providers is cached,
+// emptying the in-memory cache in front of it
+func SetTokenMetadataStore(store TokenMetadataStore) {
+	metadataStore.Lock()
+	defer metadataStore.Unlock()
+	metadataStore.store = store
+	metadataCache.clear()
+}
+
+func tokenMetadataStore() TokenMetadataStore {
+	metadataStore.RLock()
+	defer metadataStore.RUnlock()
+	return metadataStore.store
+}
+
+var (
+	nameSelector     = methodID("name()")
+	symbolSelector   = methodID("symbol()")
+	decimalsSelector = methodID("decimals()")
+)
+
+// getTokenMetadata returns metadata keyed by the given token addresses. Metadata cached
+// in memory is used first, then the store's; the rest comes from alchemy_getTokenMetadata, one call per token sent in
+// batches, and then from the token contracts' own name(), symbol() and decimals(). Newly
+// found metadata is cached, and tokens without usable metadata are left out.
+func (c *AlchemyClient) getTokenMetadata(ctx context.Context, addresses []string, chainID int) (map[string]TokenMetadata, error) {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, address := range addresses {
+		key := strings.ToLower(address)
+		if !seen[key] {
+			seen[key] = true
+			missing = append(missing, key)
+		}
+	}
+
+	store := tokenMetadataStore()
+	found := make(map[string]TokenMetadata)
+	if store != nil {
+		found = metadataCache.get(chainID, missing)
+		missing = withoutFound(missing, found)
+	}
+
+	if store != nil && len(missing) > 0 {
+		cached, err := store.GetTokensByAddresses(ctx, chainID, missing)
+		if err != nil {
+			logger.Warn("Failed to read cached token metadata", "error", err, "chainId", chainID)
+		}
+		stored := make(map[string]TokenMetadata, len(cached))
+		for key, token := range cached {
+			meta := TokenMetadata{Decimals: token.Decimals, Name: token.Name, Symbol: token.Symbol}
+			if token.LogoURI != nil {
+				meta.Logo = *token.LogoURI
+			}
+			stored[key] = meta
+			found[key] = meta
+		}
+		metadataCache.put(chainID, stored)
+		missing = withoutFound(missing, found)
+	}
+
+	var fetched []*models.Token
+	if len(missing) > 0 {
+		provided, err := c.fetchProviderMetadata(ctx, chainID, missing)
+		if err != nil {
+			logger.Warn("Failed to fetch token metadata from provider", "error", err, "chainId", chainID)
+		}
+		onChain, err := c.fetchOnChainMetadata(ctx, chainID, withoutFound(missing, provided))
+		if err != nil {
+			logger.Warn("Failed to read token metadata on-chain", "error", err, "chainId", chainID)
+		}
+		for _, source := range []map[string]TokenMetadata{provided, onChain} {
+			for key, meta := range source {
+				found[key] = meta
+				token := &models.Token{Address: key, ChainID: chainID, Symbol: meta.Symbol, Name: meta.Name, Decimals: meta.Decimals}
+				if meta.Logo != "" {
+					logo := meta.Logo
+					token.LogoURI = &logo
+				}
+				fetched = append(fetched, token)
+			}
+		}
+	}
+
+	if store != nil && len(fetched) > 0 {
+		if err := store.UpsertTokens(ctx, fetched); err != nil {
+			logger.Warn("Failed to cache token metadata", "error", err, "chainId", chainID)
+		} else {
+			stored := make(map[string]TokenMetadata, len(fetched))
+			for _, token := range fetched {
+				stored[token.Address] = found[token.Address]
+			}
+			metadataCache.put(chainID, stored)
+		}
+	}
+
+	result := make(map[string]TokenMetadata)
+	for _, address := range addresses {
+		if meta, ok := found[strings.ToLower(address)]; ok {
+			result[address] = meta
+		}
+	}
+	return result, nil
+}
+
+// withoutFound returns the addresses not yet in found
+func withoutFound(addresses []string, found map[string]TokenMetadata) []string {
+	var rest []string
+	for _, address := range addresses {
+		if _, ok := found[address]; !ok {
+			rest = append(rest, address)
+		}
+	}
+	return rest
+}
+
+// fetchProviderMetadata looks tokens up with alchemy_getTokenMetadata, which takes a
+// single address per call. Tokens it has no symbol or decimals for are left out.
+func (c *AlchemyClient) fetchProviderMetadata(ctx context.Context, chainID int, addresses []string) (map[string]TokenMetadata, error) {
+	found := make(map[string]TokenMetadata)
+	if len(addresses) == 0 {
+		return found, nil
+	}
+
+	requests := make([]rpcRequest, len(addresses))
+	for i, address := range addresses {
+		requests[i] = rpcRequest{Method: "alchemy_getTokenMetadata", Params: []interface{}{address}}
+	}
+	responses, err := c.batchCall(ctx, chainID, requests)
+	if err != nil {
+		return found, err
+	}
+
+	for i, response := range responses {
+		if response.Error != nil {
+			continue
+		}
+		var meta struct {
+			Decimals *int    `json:"decimals"`
+			Logo     *string `json:"logo"`
+			Name     string  `json:"name"`
+			Symbol   string  `json:"symbol"`
+		}
+		if err := json.Unmarshal(response.Result, &meta); err != nil || meta.Decimals == nil || meta.Symbol == "" {
+			continue
+		}
+		token := TokenMetadata{Decimals: *meta.Decimals, Name: meta.Name, Symbol: meta.Symbol}
+		if token.Name == "" {
+			token.Name = token.Symbol
+		}
+		if meta.Logo != nil {
+			token.Logo = *meta.Logo
+		}
+		found[addresses[i]] = token
+	}
+	return found, nil
+}
+
+// fetchOnChainMetadata reads name(), symbol() and decimals() from the token contracts.
+// Tokens without a readable symbol or decimals are left out.
+func (c *AlchemyClient) fetchOnChainMetadata(ctx context.Context, chainID int, addresses []string) (map[string]TokenMetadata, error) {
+	found := make(map[string]TokenMetadata)
+	if len(addresses) == 0 {
+		return found, nil
+	}
+
+	selectors := [][]byte{nameSelector, symbolSelector, decimalsSelector}
+	requests := make([]rpcRequest, 0, len(addresses)*len(selectors))
+	for _, address := range addresses {
+		for _, selector := range selectors {
+			requests = append(requests, rpcRequest{Method: "eth_call", Params: []interface{}{
+				map[string]string{"to": address, "data": "0x" + hex.EncodeToString(selector)},
+				"latest",
+			}})
+		}
+	}
+	responses, err := c.batchCall(ctx, chainID, requests)
+	if err != nil {
+		return found, err
+	}
+
+	for i, address := range addresses {
+		results := responses[i*len(selectors) : (i+1)*len(selectors)]
+		symbol, ok := decodeStringResult(results[1])
+		if !ok {
+			continue
+		}
+		decimalsData, err := decodeCallResult(results[2])
+		if err != nil || len(decimalsData) < 32 {
+			continue
+		}
+		decimals := decodeUint(decimalsData, 0)
+		if !decimals.IsInt64() || decimals.Int64() > 255 {
+			continue
+		}
+		name, ok := decodeStringResult(results[0])
+		if !ok {
+			name = symbol
+		}
+		found[address] = TokenMetadata{Decimals: int(decimals.Int64()), Name: name, Symbol: symbol}
+	}
+	return found, nil
+}
+
+// decodeCallResult returns the bytes an eth_call returned
+func decodeCallResult(response rpcResponse) ([]byte, error) {
+	if response.Error != nil {
+		return nil, response.Error
+	}
+	var result string
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid call result: %w", err)
+	}
+	if !hexutil.Has0xPrefix(result) {
+		return nil, fmt.Errorf("%w: %q", hexutil.ErrMissingPrefix, result)
+	}
+	return hex.DecodeString(result[2:])
+}
+
+// decodeStringResult decodes a non-empty string returned by an eth_call
+func decodeStringResult(response rpcResponse) (string, bool) {
+	data, err := decodeCallResult(response)
+	if err != nil {
+		return "", false
+	}
+	s, err := decodeABIString(data)
+	if err != nil || s == "" {
+		return "", false
+	}
+	return s, true
+}
+
+// decodeABIString decodes an ABI-encoded string return value. Older tokens such as MKR
+// return bytes32 instead, padded with zero bytes.
+func decodeABIString(data []byte) (string, error) {
+	var raw []byte
+	switch {
+	case len(data) == 32:
+		raw = data
+	case len(data) >= 64:
+		offset := decodeUint(data, 0)
+		if !offset.IsInt64() || offset.Int64() > int64(len(data)-32) {
+			return "", errors.New("string offset out of range")
+		}
+		start := int(offset.Int64())
+		length := decodeUint(data[start:], 0)
+		if !length.IsInt64() || length.Int64() > int64(len(data)-start-32) {
+			return "", errors.New("string length out of range")
+		}
+		raw = data[start+32 : start+32+int(length.Int64())]
+	default:
+		return "", fmt.Errorf("unexpected string result of %d bytes", len(data))
+	}
+
+	s := strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+	if !utf8.ValidString(s) || strings.ContainsRune(s, 0) {
+		return "", errors.New("string is not valid UTF-8")
+	}
+	return s, nil
+}
@@ -0,0 +1,155 @@
+package blockchain
+
+import (
+	"container/list"
+	"expvar"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+)
+
+const (
+	// tokenMetadataCacheSize bounds the tokens kept in memory per process
+	tokenMetadataCacheSize = 10_000
+	// tokenMetadataCacheTTL bounds how long another process's metadata update can go
+	// unseen here
+	tokenMetadataCacheTTL = time.Hour
+)
+
+// tokenMetadataCache is a least-recently-used cache of token metadata in front of the
+// metadata store, so portfolio builds don't read the same hot tokens from the database
+// on every request. Tokens are keyed by chain and lowercase address.
+type tokenMetadataCache struct {
+	size  int
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+
+	hits, misses, evictions expvar.Int
+}
+
+type tokenMetadataEntry struct {
+	key       string
+	meta      TokenMetadata
+	expiresAt time.Time
+}
+
+func newTokenMetadataCache(size int, ttl time.Duration) *tokenMetadataCache {
+	return &tokenMetadataCache{
+		size:    size,
+		ttl:     ttl,
+		clock:   clock.System,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// metadataCache is shared by every client, like the store behind it
+var metadataCache = newTokenMetadataCache(tokenMetadataCacheSize, tokenMetadataCacheTTL)
+
+func tokenMetadataKey(chainID int, address string) string {
+	return strconv.Itoa(chainID) + ":" + strings.ToLower(address)
+}
+
+// get returns the cached metadata of the given lowercase addresses
+func (c *tokenMetadataCache) get(chainID int, addresses []string) map[string]TokenMetadata {
+	found := make(map[string]TokenMetadata)
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, address := range addresses {
+		elem, ok := c.entries[tokenMetadataKey(chainID, address)]
+		if !ok {
+			c.misses.Add(1)
+			continue
+		}
+		entry := elem.Value.(*tokenMetadataEntry)
+		if now.After(entry.expiresAt) {
+			c.remove(elem)
+			c.misses.Add(1)
+			continue
+		}
+		c.order.MoveToFront(elem)
+		found[address] = entry.meta
+		c.hits.Add(1)
+	}
+	return found
+}
+
+// put caches metadata keyed by lowercase address, evicting the least recently used
+// tokens over the size
+func (c *tokenMetadataCache) put(chainID int, metadata map[string]TokenMetadata) {
+	expiresAt := c.clock.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for address, meta := range metadata {
+		key := tokenMetadataKey(chainID, address)
+		if elem, ok := c.entries[key]; ok {
+			entry := elem.Value.(*tokenMetadataEntry)
+			entry.meta, entry.expiresAt = meta, expiresAt
+			c.order.MoveToFront(elem)
+			continue
+		}
+		c.entries[key] = c.order.PushFront(&tokenMetadataEntry{key: key, meta: meta, expiresAt: expiresAt})
+	}
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// invalidate drops tokens so their next lookup reads the store
+func (c *tokenMetadataCache) invalidate(chainID int, addresses []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, address := range addresses {
+		if elem, ok := c.entries[tokenMetadataKey(chainID, address)]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+func (c *tokenMetadataCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *tokenMetadataCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*tokenMetadataEntry).key)
+}
+
+// stats reports the cache's size and how often lookups were answered from it
+func (c *tokenMetadataCache) stats() map[string]int64 {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return map[string]int64{
+		"size":      int64(size),
+		"hits":      c.hits.Value(),
+		"misses":    c.misses.Value(),
+		"evictions": c.evictions.Value(),
+	}
+}
+
+// InvalidateTokenMetadata drops tokens from this process's metadata cache. Call it after
+// changing a token's symbol, name, decimals or logo other than through a client lookup.
+func InvalidateTokenMetadata(chainID int, addresses ...string) {
+	metadataCache.invalidate(chainID, addresses)
+}
+
+// PublishTokenMetadataCache exposes the metadata cache's hit and miss counts as the
+// expvar variable name
+func PublishTokenMetadataCache(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return metadataCache.stats() }))
+}
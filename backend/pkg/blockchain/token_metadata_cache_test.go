@@ -0,0 +1,74 @@
+package blockchain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenMetadataCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := newTokenMetadataCache(2, time.Hour)
+	c.clock = clk
+
+	c.put(1, map[string]TokenMetadata{"0xa": {Symbol: "A"}, "0xb": {Symbol: "B"}})
+	// Reading A makes B the least recently used
+	assert.Len(t, c.get(1, []string{"0xa"}), 1)
+	c.put(1, map[string]TokenMetadata{"0xc": {Symbol: "C"}})
+
+	found := c.get(1, []string{"0xa", "0xb", "0xc"})
+	assert.Equal(t, "A", found["0xa"].Symbol)
+	assert.Equal(t, "C", found["0xc"].Symbol)
+	assert.NotContains(t, found, "0xb")
+	assert.Empty(t, c.get(137, []string{"0xa"}), "keyed by chain")
+
+	clk.Advance(time.Hour + time.Second)
+	assert.Empty(t, c.get(1, []string{"0xa"}), "expired")
+
+	stats := c.stats()
+	assert.Equal(t, int64(3), stats["hits"])
+	assert.Equal(t, int64(3), stats["misses"])
+	assert.Equal(t, int64(1), stats["evictions"])
+	assert.Equal(t, int64(1), stats["size"])
+}
+
+type countingTokenStore struct {
+	memoryTokenStore
+	reads int
+}
+
+func (s *countingTokenStore) GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error) {
+	s.reads++
+	return s.memoryTokenStore.GetTokensByAddresses(ctx, chainID, addresses)
+}
+
+func TestGetTokenMetadataReadsStoreOnce(t *testing.T) {
+	const token = "0x3333333333333333333333333333333333333333"
+
+	store := &countingTokenStore{memoryTokenStore: memoryTokenStore{tokens: map[string]*models.Token{
+		token: {Address: token, ChainID: 1, Symbol: "CCH", Name: "Cached", Decimals: 8},
+	}}}
+	SetTokenMetadataStore(store)
+	defer SetTokenMetadataStore(nil)
+
+	client := &AlchemyClient{}
+	for i := 0; i < 3; i++ {
+		metadata, err := client.getTokenMetadata(context.Background(), []string{token}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "CCH", metadata[token].Symbol)
+	}
+	assert.Equal(t, 1, store.reads)
+
+	// A renamed token is read again once invalidated
+	store.tokens[token].Symbol = "NEW"
+	InvalidateTokenMetadata(1, "0x3333333333333333333333333333333333333333")
+	metadata, err := client.getTokenMetadata(context.Background(), []string{token}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "NEW", metadata[token].Symbol)
+	assert.Equal(t, 2, store.reads)
+}
@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryTokenStore struct {
+	tokens map[string]*models.Token
+}
+
+func (s *memoryTokenStore) GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error) {
+	found := make(map[string]*models.Token)
+	for _, address := range addresses {
+		if token, ok := s.tokens[address]; ok {
+			found[address] = token
+		}
+	}
+	return found, nil
+}
+
+func (s *memoryTokenStore) UpsertTokens(ctx context.Context, tokens []*models.Token) error {
+	for _, token := range tokens {
+		s.tokens[token.Address] = token
+	}
+	return nil
+}
+
+// abiString encodes s as an ABI string return value
+func abiString(s string) string {
+	data := append(encodeUint(big.NewInt(32)), encodeUint(big.NewInt(int64(len(s))))...)
+	padded := make([]byte, (len(s)+31)/32*32)
+	copy(padded, s)
+	return "0x" + hex.EncodeToString(append(data, padded...))
+}
+
+func TestDecodeABIString(t *testing.T) {
+	data, _ := hex.DecodeString(strings.TrimPrefix(abiString("USD Coin"), "0x"))
+	s, err := decodeABIString(data)
+	require.NoError(t, err)
+	assert.Equal(t, "USD Coin", s)
+
+	// bytes32, as returned by MKR
+	bytes32 := make([]byte, 32)
+	copy(bytes32, "MKR")
+	s, err = decodeABIString(bytes32)
+	require.NoError(t, err)
+	assert.Equal(t, "MKR", s)
+
+	_, err = decodeABIString(data[:40])
+	assert.Error(t, err, "truncated")
+	_, err = decodeABIString(append(encodeUint(big.NewInt(1<<20)), data[32:]...))
+	assert.Error(t, err, "offset past the end")
+}
+
+func TestGetTokenMetadata(t *testing.T) {
+	const (
+		usdc    = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		unknown = "0x1111111111111111111111111111111111111111"
+		broken  = "0x2222222222222222222222222222222222222222"
+		cached  = "0x3333333333333333333333333333333333333333"
+	)
+
+	var batches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batches++
+		var calls []struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&calls))
+
+		var responses []map[string]interface{}
+		for _, call := range calls {
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": call.ID}
+			switch call.Method {
+			case "alchemy_getTokenMetadata":
+				var address string
+				require.NoError(t, json.Unmarshal(call.Params[0], &address))
+				if strings.EqualFold(address, usdc) {
+					response["result"] = map[string]interface{}{"decimals": 6, "logo": "https://logo", "name": "USD Coin", "symbol": "USDC"}
+				} else {
+					response["result"] = map[string]interface{}{"decimals": nil, "logo": nil, "name": "", "symbol": ""}
+				}
+			case "eth_call":
+				var tx map[string]string
+				require.NoError(t, json.Unmarshal(call.Params[0], &tx))
+				if tx["to"] != unknown {
+					response["error"] = map[string]interface{}{"code": -32000, "message": "execution reverted"}
+					break
+				}
+				switch tx["data"] {
+				case "0x" + hex.EncodeToString(nameSelector):
+					response["result"] = abiString("Unknown Token")
+				case "0x" + hex.EncodeToString(symbolSelector):
+					response["result"] = abiString("UNK")
+				case "0x" + hex.EncodeToString(decimalsSelector):
+					response["result"] = "0x" + hex.EncodeToString(encodeUint(big.NewInt(9)))
+				}
+			}
+			responses = append(responses, response)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(responses))
+	}))
+	defer server.Close()
+
+	store := &memoryTokenStore{tokens: map[string]*models.Token{
+		cached: {Address: cached, ChainID: 1, Symbol: "CCH", Name: "Cached", Decimals: 8},
+	}}
+	SetTokenMetadataStore(store)
+	defer SetTokenMetadataStore(nil)
+
+	client := &AlchemyClient{httpClient: server.Client(), baseURLs: map[int]string{1: server.URL}}
+	metadata, err := client.getTokenMetadata(context.Background(), []string{usdc, unknown, broken, cached}, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, TokenMetadata{Decimals: 6, Logo: "https://logo", Name: "USD Coin", Symbol: "USDC"}, metadata[usdc], "keyed by the address as given")
+	assert.Equal(t, TokenMetadata{Decimals: 9, Name: "Unknown Token", Symbol: "UNK"}, metadata[unknown], "read on-chain")
+	assert.Equal(t, "CCH", metadata[cached].Symbol)
+	assert.NotContains(t, metadata, broken)
+	assert.Equal(t, 2, batches, "one provider batch and one on-chain batch")
+
+	// Fetched metadata is cached, so a second lookup only retries the broken token
+	assert.Equal(t, "USDC", store.tokens[strings.ToLower(usdc)].Symbol)
+	assert.Equal(t, "UNK", store.tokens[unknown].Symbol)
+	batches = 0
+	metadata, err = client.getTokenMetadata(context.Background(), []string{usdc, unknown}, 1)
+	require.NoError(t, err)
+	assert.Len(t, metadata, 2)
+	assert.Zero(t, batches)
+}
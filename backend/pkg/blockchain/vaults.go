@@ -0,0 +1,182 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	selAsset           = methodID("asset()")
+	selTotalAssets     = methodID("totalAssets()")
+	selTotalSupply     = methodID("totalSupply()")
+	selConvertToAssets = methodID("convertToAssets(uint256)")
+)
+
+// SupportsVaults reports whether ERC-4626 vaults can be read on the chain
+func (s *BlockchainService) SupportsVaults(chainID int) bool {
+	_, ok := s.alchemyClient.baseURL(chainID)
+	return ok
+}
+
+// GetVault reads an ERC-4626 vault's underlying asset, size and share price. Addresses
+// that don't answer asset() and totalAssets() aren't vaults and are an error.
+func (s *BlockchainService) GetVault(ctx context.Context, chainID int, vault string) (*models.Vault, error) {
+	if !common.IsHexAddress(vault) {
+		return nil, fmt.Errorf("invalid vault address: %q", vault)
+	}
+
+	results, err := s.alchemyClient.multicall(ctx, chainID, []Call{
+		newCall(vault, selAsset),
+		newCall(vault, decimalsSelector),
+		newCall(vault, selTotalAssets),
+		newCall(vault, selTotalSupply),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !results[0].Success || len(results[0].ReturnData) < 32 {
+		return nil, fmt.Errorf("%s is not an ERC-4626 vault", vault)
+	}
+	asset := decodeAddress(results[0].ReturnData, 0).Hex()
+	shareDecimals, totalAssets, totalSupply := uintResult(results[1]), uintResult(results[2]), uintResult(results[3])
+	if shareDecimals == nil || totalAssets == nil || totalSupply == nil || !shareDecimals.IsInt64() {
+		return nil, fmt.Errorf("%s is not an ERC-4626 vault", vault)
+	}
+
+	metadata, err := s.alchemyClient.getTokenMetadata(ctx, []string{asset}, chainID)
+	if err != nil {
+		return nil, err
+	}
+	assetMeta, ok := metadata[strings.ToLower(asset)]
+	if !ok {
+		return nil, fmt.Errorf("no metadata for vault asset %s", asset)
+	}
+
+	return &models.Vault{
+		ChainID:       chainID,
+		Address:       common.HexToAddress(vault).Hex(),
+		Asset:         asset,
+		AssetSymbol:   assetMeta.Symbol,
+		AssetDecimals: assetMeta.Decimals,
+		ShareDecimals: int(shareDecimals.Int64()),
+		TotalAssets:   scaleDown(totalAssets, assetMeta.Decimals),
+		TotalSupply:   scaleDown(totalSupply, int(shareDecimals.Int64())),
+		SharePrice:    sharePrice(totalAssets, totalSupply, assetMeta.Decimals, int(shareDecimals.Int64())),
+	}, nil
+}
+
+// GetVaultPositions reads a wallet's shares in the given ERC-4626 vaults and what they
+// redeem for. Vaults the wallet holds no shares in, and addresses that turn out not to be
+// vaults, are left out.
+func (s *BlockchainService) GetVaultPositions(ctx context.Context, address string, chainID int, vaults []string) ([]*models.VaultPosition, error) {
+	if len(vaults) == 0 {
+		return nil, nil
+	}
+
+	calls := make([]Call, len(vaults))
+	for i, vault := range vaults {
+		calls[i] = newCall(vault, selBalanceOf, encodeAddress(address))
+	}
+	results, err := s.alchemyClient.multicall(ctx, chainID, calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault shares: %w", err)
+	}
+
+	var held []string
+	shares := make(map[string]*big.Int)
+	for i, vault := range vaults {
+		if balance := uintResult(results[i]); balance != nil && balance.Sign() > 0 {
+			held = append(held, vault)
+			shares[vault] = balance
+		}
+	}
+	if len(held) == 0 {
+		return nil, nil
+	}
+
+	// Three calls per held vault: the asset, the share decimals and the shares' value
+	calls = make([]Call, 0, 3*len(held))
+	for _, vault := range held {
+		calls = append(calls,
+			newCall(vault, selAsset),
+			newCall(vault, decimalsSelector),
+			newCall(vault, selConvertToAssets, encodeUint(shares[vault])))
+	}
+	if results, err = s.alchemyClient.multicall(ctx, chainID, calls); err != nil {
+		return nil, fmt.Errorf("failed to read vaults: %w", err)
+	}
+
+	type heldVault struct {
+		vault, asset  string
+		shareDecimals int
+		assets        *big.Int
+	}
+	var readable []heldVault
+	var assets []string
+	for i, vault := range held {
+		assetResult, decimals, value := results[3*i], uintResult(results[3*i+1]), uintResult(results[3*i+2])
+		if !assetResult.Success || len(assetResult.ReturnData) < 32 || decimals == nil || value == nil || !decimals.IsInt64() {
+			continue
+		}
+		asset := decodeAddress(assetResult.ReturnData, 0).Hex()
+		readable = append(readable, heldVault{vault: vault, asset: asset, shareDecimals: int(decimals.Int64()), assets: value})
+		assets = append(assets, asset)
+	}
+	if len(readable) == 0 {
+		return nil, nil
+	}
+
+	metadata, err := s.alchemyClient.getTokenMetadata(ctx, assets, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*models.VaultPosition, 0, len(readable))
+	for _, h := range readable {
+		assetMeta, ok := metadata[strings.ToLower(h.asset)]
+		if !ok {
+			continue
+		}
+		position := vaultPosition(shares[h.vault], h.assets, h.shareDecimals, assetMeta.Decimals)
+		position.ChainID = chainID
+		position.Vault = common.HexToAddress(h.vault).Hex()
+		position.WalletAddress = address
+		position.Asset = h.asset
+		position.AssetSymbol = assetMeta.Symbol
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+// GetVaultShares returns how many shares of an ERC-4626 vault owner holds
+func (s *BlockchainService) GetVaultShares(ctx context.Context, chainID int, vault, owner string) (*big.Int, error) {
+	out, err := s.alchemyClient.ethCall(ctx, chainID, vault, selBalanceOf, encodeAddress(owner))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault shares: %w", err)
+	}
+	return decodeUint(out, 0), nil
+}
+
+// vaultPosition values shares by what the vault converts them to
+func vaultPosition(shares, assets *big.Int, shareDecimals, assetDecimals int) *models.VaultPosition {
+	return &models.VaultPosition{
+		SharesRaw:  shares.String(),
+		Shares:     scaleDown(shares, shareDecimals),
+		AssetsRaw:  assets.String(),
+		Assets:     scaleDown(assets, assetDecimals),
+		SharePrice: sharePrice(assets, shares, assetDecimals, shareDecimals),
+	}
+}
+
+// sharePrice returns the assets one whole share is worth, 1 for an empty vault
+func sharePrice(assets, shares *big.Int, assetDecimals, shareDecimals int) float64 {
+	if shares.Sign() == 0 {
+		return 1
+	}
+	return scaleDown(assets, assetDecimals) / scaleDown(shares, shareDecimals)
+}
@@ -0,0 +1,26 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultPosition(t *testing.T) {
+	// 100 shares with 18 decimals redeeming for 105 USDC
+	shares, _ := new(big.Int).SetString("100000000000000000000", 10)
+	position := vaultPosition(shares, big.NewInt(105_000_000), 18, 6)
+
+	assert.Equal(t, "100000000000000000000", position.SharesRaw)
+	assert.Equal(t, "105000000", position.AssetsRaw)
+	assert.InDelta(t, 100.0, position.Shares, 1e-9)
+	assert.InDelta(t, 105.0, position.Assets, 1e-9)
+	assert.InDelta(t, 1.05, position.SharePrice, 1e-9)
+}
+
+func TestSharePrice(t *testing.T) {
+	assert.InDelta(t, 2.0, sharePrice(big.NewInt(2_000_000), big.NewInt(1_000_000), 6, 6), 1e-12)
+	// An empty vault mints shares one for one
+	assert.Equal(t, 1.0, sharePrice(big.NewInt(0), big.NewInt(0), 6, 6))
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// AlertListParams narrow and page ListAlerts. A zero Limit takes the API's default.
+type AlertListParams struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+// ListAlerts returns a page of the signed-in user's alerts
+func (c *Client) ListAlerts(ctx context.Context, params *AlertListParams) ([]*Alert, *Page, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Status != "" {
+			query.Set("status", params.Status)
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Offset != 0 {
+			query.Set("offset", strconv.Itoa(params.Offset))
+		}
+	}
+
+	var alerts []*Alert
+	var page Page
+	if err := c.call(ctx, opGetAlerts, nil, query, nil, &alerts, &page); err != nil {
+		return nil, nil, err
+	}
+	return alerts, &page, nil
+}
+
+// GetAlert returns one of the signed-in user's alerts
+func (c *Client) GetAlert(ctx context.Context, alertID string) (*Alert, error) {
+	var alert Alert
+	if err := c.call(ctx, opGetAlert, []string{alertID}, nil, nil, &alert, nil); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// CreateAlert creates an alert for the signed-in user
+func (c *Client) CreateAlert(ctx context.Context, req *CreateAlertRequest) (*Alert, error) {
+	var alert Alert
+	if err := c.call(ctx, opCreateAlert, nil, nil, req, &alert, nil); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// UpdateAlert changes the fields of an alert set in req
+func (c *Client) UpdateAlert(ctx context.Context, alertID string, req *UpdateAlertRequest) (*Alert, error) {
+	var alert Alert
+	if err := c.call(ctx, opUpdateAlert, []string{alertID}, nil, req, &alert, nil); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// DeleteAlert deletes one of the signed-in user's alerts
+func (c *Client) DeleteAlert(ctx context.Context, alertID string) error {
+	return c.call(ctx, opDeleteAlert, []string{alertID}, nil, nil, nil, nil)
+}
\ No newline at end of file
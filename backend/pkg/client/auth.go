@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetNonce returns a nonce and the SIWE message for address to sign. A zero chainID
+// signs for mainnet.
+func (c *Client) GetNonce(ctx context.Context, address string, chainID int) (*NonceResponse, error) {
+	body := map[string]any{"address": address}
+	if chainID != 0 {
+		body["chainId"] = chainID
+	}
+	var nonce NonceResponse
+	if err := c.call(ctx, opGetSiweNonce, nil, nil, body, &nonce, nil); err != nil {
+		return nil, err
+	}
+	return &nonce, nil
+}
+
+// Verify signs in with a signed SIWE message, authenticating later requests with the
+// session's token
+func (c *Client) Verify(ctx context.Context, message, signature string) (*AuthResponse, error) {
+	var auth AuthResponse
+	body := map[string]string{"message": message, "signature": signature}
+	if err := c.call(ctx, opVerifySiwe, nil, nil, body, &auth, nil); err != nil {
+		return nil, err
+	}
+	c.SetToken(auth.Token)
+	return &auth, nil
+}
+
+// SignIn signs in as address, having sign sign the SIWE message, e.g. with the wallet's
+// personal_sign
+func (c *Client) SignIn(ctx context.Context, address string, chainID int, sign func(message string) (string, error)) (*AuthResponse, error) {
+	nonce, err := c.GetNonce(ctx, address, chainID)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := sign(nonce.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign SIWE message: %w", err)
+	}
+	return c.Verify(ctx, nonce.Message, signature)
+}
+
+// Me returns the signed-in user
+func (c *Client) Me(ctx context.Context) (*UserProfile, error) {
+	var profile UserProfile
+	if err := c.call(ctx, opGetCurrentUser, nil, nil, nil, &profile, nil); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
\ No newline at end of file
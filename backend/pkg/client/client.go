@@ -0,0 +1,233 @@
+// Package client is a Go SDK for the API, covering auth, portfolios, alerts and swap and
+// bridge quotes. It talks to /api/v2, where every response is in the standard envelope.
+// Paths come from the OpenAPI spec through operations.gen.go; models are those of
+// internal/models, or mirror the service types the handlers return.
+//
+//	c := client.New("https://api.example.com")
+//	if err := c.SignIn(ctx, address, 1, wallet.SignMessage); err != nil { ... }
+//	balances, err := c.GetBalances(ctx, address, nil)
+package client
+
+//go:generate go run ../../cmd/clientgen -spec ../../../spec/openapi.yaml -out operations.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+)
+
+// basePath is where the API version the SDK speaks is served
+const basePath = "/api/v2"
+
+// defaultTimeout bounds a call when the caller's context has no deadline
+const defaultTimeout = 30 * time.Second
+
+// operation is an API operation of the spec, with its path template, e.g.
+// /alerts/{alertId}
+type operation struct {
+	ID     string
+	Method string
+	Path   string
+}
+
+// Client calls the API. It's safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	keys       ProviderKeys
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through httpClient instead of a default one
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken authenticates requests with a JWT from an earlier sign-in
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithProviderKeys sends the caller's own provider API keys, which the API uses ahead of
+// the user's stored keys and the platform's
+func WithProviderKeys(keys ProviderKeys) Option {
+	return func(c *Client) {
+		c.keys = keys
+	}
+}
+
+// New returns a client of the API at baseURL, e.g. https://api.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetToken replaces the JWT requests are authenticated with; empty signs out
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// Token returns the JWT requests are authenticated with
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    any
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("api error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// envelope is the standard response body
+type envelope struct {
+	Data   json.RawMessage `json:"data"`
+	Meta   json.RawMessage `json:"meta"`
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details any    `json:"details"`
+	} `json:"errors"`
+}
+
+// call makes an operation, filling the path template with params in order. A non-nil
+// body is sent as JSON; data and meta, when non-nil, receive the envelope's fields.
+func (c *Client) call(ctx context.Context, op operation, params []string, query url.Values, body, data, meta any) error {
+	path, err := op.expand(params...)
+	if err != nil {
+		return err
+	}
+	endpoint := c.baseURL + basePath + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s request: %w", op.ID, err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.Method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build %s request: %w", op.ID, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for header, key := range map[string]string{
+		"X-Alchemy-API-Key":   c.keys.Alchemy,
+		"X-CoinGecko-API-Key": c.keys.CoinGecko,
+		"X-Etherscan-API-Key": c.keys.Etherscan,
+		"X-Infura-API-Key":    c.keys.Infura,
+	} {
+		if key != "" {
+			req.Header.Set(header, key)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", op.ID, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", op.ID, err)
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	var decoded envelope
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		if resp.StatusCode >= 400 {
+			return &Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode), Message: strings.TrimSpace(string(raw))}
+		}
+		return fmt.Errorf("failed to decode %s response: %w", op.ID, err)
+	}
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+		if len(decoded.Errors) > 0 {
+			apiErr.Code = decoded.Errors[0].Code
+			apiErr.Message = decoded.Errors[0].Message
+			apiErr.Details = decoded.Errors[0].Details
+		}
+		return apiErr
+	}
+
+	if data != nil && len(decoded.Data) > 0 {
+		if err := json.Unmarshal(decoded.Data, data); err != nil {
+			return fmt.Errorf("failed to decode %s data: %w", op.ID, err)
+		}
+	}
+	if meta != nil && len(decoded.Meta) > 0 {
+		if err := json.Unmarshal(decoded.Meta, meta); err != nil {
+			return fmt.Errorf("failed to decode %s meta: %w", op.ID, err)
+		}
+	}
+	return nil
+}
+
+// expand fills the operation's path parameters in the order they appear
+func (op operation) expand(params ...string) (string, error) {
+	path := op.Path
+	for _, param := range params {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			return "", fmt.Errorf("%s takes %d path parameters, got %d", op.ID, strings.Count(op.Path, "{"), len(params))
+		}
+		path = path[:start] + url.PathEscape(param) + path[end+1:]
+	}
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("%s takes %d path parameters, got %d", op.ID, strings.Count(op.Path, "{"), len(params))
+	}
+	return path, nil
+}
\ No newline at end of file
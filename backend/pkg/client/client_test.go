@@ -0,0 +1,172 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/clientgen"
+	"github.com/defi-dashboard/backend/internal/handlers"
+	"github.com/defi-dashboard/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCalls(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "POST /api/v2/auth/siwe/nonce":
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, float64(8453), body["chainId"])
+			w.Write([]byte(`{"data":{"nonce":"abc","message":"sign me"}}`))
+		case "POST /api/v2/auth/siwe/verify":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, map[string]string{"message": "sign me", "signature": "0xsigned"}, body)
+			w.Write([]byte(`{"data":{"token":"jwt","expires_in":86400,"address":"0xabc"}}`))
+		case "GET /api/v2/alerts":
+			w.Write([]byte(`{"data":[{"type":"price_above","status":"active"}],"meta":{"page":2,"limit":1,"total":3,"totalPages":3}}`))
+		case "DELETE /api/v2/alerts/a%2Fb":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /api/v2/portfolio/0xabc/history":
+			w.Write([]byte(`{"data":{"history":[{"timestamp":"2026-01-01T00:00:00Z","total_value":12.5}]}}`))
+		case "POST /api/v2/bridge/routes":
+			w.Write([]byte(`{"data":{"routes":[{"id":"r1","steps":[{"type":"bridge"}]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"data":null,"errors":[{"code":"NOT_FOUND","message":"Alert not found"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(server.URL+"/", WithProviderKeys(ProviderKeys{Alchemy: "alchemy-key"}))
+
+	auth, err := c.SignIn(ctx, "0xabc", 8453, func(message string) (string, error) {
+		assert.Equal(t, "sign me", message)
+		return "0xsigned", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 86400, auth.ExpiresIn)
+	assert.Equal(t, "jwt", c.Token())
+
+	alerts, page, err := c.ListAlerts(ctx, &AlertListParams{Status: AlertStatusActive, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "price_above", alerts[0].Type)
+	assert.Equal(t, Page{Page: 2, Limit: 1, Total: 3, TotalPages: 3}, *page)
+	last := requests[len(requests)-1]
+	assert.Equal(t, "Bearer jwt", last.Header.Get("Authorization"))
+	assert.Equal(t, "alchemy-key", last.Header.Get("X-Alchemy-API-Key"))
+	assert.Equal(t, "limit=1&offset=1&status=active", last.URL.RawQuery)
+
+	require.NoError(t, c.DeleteAlert(ctx, "a/b"))
+
+	history, err := c.GetPortfolioHistory(ctx, "0xabc", nil)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 12.5, history[0].TotalValue)
+
+	routes, err := c.GetBridgeRoutes(ctx, &BridgeRouteRequest{FromChain: 1, ToChain: 10})
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "bridge", routes[0].Steps[0].Type)
+
+	_, err = c.GetAlert(ctx, "missing")
+	require.Error(t, err)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, "Alert not found", err.(*Error).Message)
+}
+
+func TestOperationExpand(t *testing.T) {
+	path, err := opGetPortfolioBalances.expand("0xabc")
+	require.NoError(t, err)
+	assert.Equal(t, "/portfolio/0xabc/balances", path)
+
+	_, err = opGetPortfolioBalances.expand()
+	assert.Error(t, err)
+	_, err = opGetAlerts.expand("extra")
+	assert.Error(t, err)
+}
+
+// TestOperationsGenerated fails when operations.gen.go is stale; run make generate-client
+func TestOperationsGenerated(t *testing.T) {
+	spec, err := os.ReadFile("../../../spec/openapi.yaml")
+	require.NoError(t, err)
+	want, err := clientgen.Generate(spec, "spec/openapi.yaml")
+	require.NoError(t, err)
+	got, err := os.ReadFile("operations.gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+// TestOperationsRouted checks the operations the SDK calls are served by the router
+func TestOperationsRouted(t *testing.T) {
+	file, err := os.Open("../../internal/router/testdata/v1_routes.txt")
+	require.NoError(t, err)
+	defer file.Close()
+	routes := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		routes[scanner.Text()] = true
+	}
+
+	param := regexp.MustCompile(`\{(\w+)\}`)
+	for _, op := range []operation{
+		opGetSiweNonce, opVerifySiwe, opGetCurrentUser,
+		opGetPortfolioBalances, opGetPortfolioHistory,
+		opGetAlerts, opCreateAlert, opGetAlert, opUpdateAlert, opDeleteAlert,
+		opGetSwapQuote, opGetBridgeRoutes,
+	} {
+		route := op.Method + " /api/v1" + param.ReplaceAllString(op.Path, ":$1")
+		assert.True(t, routes[route] || routes[route+"/"], "%s (%s) isn't routed", route, op.ID)
+	}
+}
+
+// TestMirroredModels checks the SDK's copies of service and handler types encode alike
+func TestMirroredModels(t *testing.T) {
+	for _, pair := range [][2]any{
+		{NonceResponse{}, handlers.NonceResponse{}},
+		{AuthResponse{}, handlers.AuthResponse{}},
+		{UserProfile{}, handlers.UserProfileResponse{}},
+		{PortfolioBalances{}, services.PortfolioBalances{}},
+		{PortfolioHistoryPoint{}, services.PortfolioHistoryPoint{}},
+		{SwapQuoteRequest{}, services.SwapQuoteRequest{}},
+		{SwapRoute{}, services.SwapRoute{}},
+		{SwapFees{}, services.SwapFees{}},
+		{BridgeRouteRequest{}, services.BridgeRouteRequest{}},
+		{BridgeRoute{}, services.BridgeRoute{}},
+		{BridgeFees{}, services.BridgeFees{}},
+		{BridgeStep{}, services.BridgeStep{}},
+		{QuoteCost{}, services.QuoteCost{}},
+		{QuoteFee{}, services.QuoteFee{}},
+		{DestinationGas{}, services.DestinationGas{}},
+		{RefuelLeg{}, services.RefuelLeg{}},
+	} {
+		assert.Equal(t, jsonFields(reflect.TypeOf(pair[1])), jsonFields(reflect.TypeOf(pair[0])), reflect.TypeOf(pair[0]).Name())
+	}
+}
+
+// mirrorPackage matches the packages of the types the SDK mirrors
+var mirrorPackage = regexp.MustCompile(`\b(services|handlers)\.`)
+
+// jsonFields maps a struct's fields to their tags and types, taking mirrored types as
+// the SDK's
+func jsonFields(typ reflect.Type) map[string]string {
+	fields := make(map[string]string, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fields[field.Name] = field.Tag.Get("json") + " " + mirrorPackage.ReplaceAllString(field.Type.String(), "client.")
+	}
+	return fields
+}
\ No newline at end of file
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// Models the API shares with the backend
+type (
+	User               = models.User
+	ChainRef           = models.ChainRef
+	DataBlock          = models.DataBlock
+	Balance            = models.Balance
+	CosmosDelegation   = models.CosmosDelegation
+	DerivativePosition = models.DerivativePosition
+	ProviderKeys       = models.ProviderKeys
+	Alert              = models.Alert
+	AlertTarget        = models.AlertTarget
+	AlertConditions    = models.AlertConditions
+	AlertNotification  = models.AlertNotification
+	CreateAlertRequest = models.CreateAlertRequest
+	UpdateAlertRequest = models.UpdateAlertRequest
+)
+
+// Alert statuses, which ListAlerts can filter by
+const (
+	AlertStatusActive    = models.AlertStatusActive
+	AlertStatusTriggered = models.AlertStatusTriggered
+	AlertStatusExpired   = models.AlertStatusExpired
+	AlertStatusDisabled  = models.AlertStatusDisabled
+)
+
+// The types below mirror those the services and handlers encode, which live in
+// packages integrators can't import. Their JSON tags must stay in step.
+
+// NonceResponse is a SIWE nonce and the message to sign with it
+type NonceResponse struct {
+	Nonce   string `json:"nonce"`
+	Message string `json:"message"`
+}
+
+// AuthResponse is a signed-in session
+type AuthResponse struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+	Address   string `json:"address"`
+	User      *User  `json:"user"`
+}
+
+// UserProfile is the signed-in user
+type UserProfile struct {
+	User    *User  `json:"user"`
+	Address string `json:"address"`
+}
+
+// PortfolioBalances is an address's balances on a chain
+type PortfolioBalances struct {
+	TotalValue       float64               `json:"total_value"`
+	Balances         []*Balance            `json:"balances"`
+	IsTestnet        bool                  `json:"is_testnet,omitempty"`
+	Chain            *ChainRef             `json:"chain,omitempty"`
+	Delegations      []*CosmosDelegation   `json:"delegations,omitempty"`
+	Derivatives      []*DerivativePosition `json:"derivatives,omitempty"`
+	DerivativesValue float64               `json:"derivatives_value,omitempty"`
+	Block            *DataBlock            `json:"block,omitempty"`
+}
+
+// PortfolioHistoryPoint is an address's value at a time
+type PortfolioHistoryPoint struct {
+	Timestamp  time.Time `json:"timestamp"`
+	TotalValue float64   `json:"total_value"`
+}
+
+// Page is where a page of a list falls
+type Page struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
+}
+
+// SwapQuoteRequest asks for quotes to swap one token for another
+type SwapQuoteRequest struct {
+	ChainID     int     `json:"chainId"`
+	FromToken   string  `json:"fromToken"`
+	ToToken     string  `json:"toToken"`
+	FromAmount  string  `json:"fromAmount"`
+	UserAddress string  `json:"userAddress"`
+	Slippage    float64 `json:"slippage"`
+	GasPrice    string  `json:"gasPrice,omitempty"`
+}
+
+// SwapRoute is an aggregator's quote for a swap, with the transaction making it
+type SwapRoute struct {
+	ID           string     `json:"id"`
+	FromToken    string     `json:"fromToken"`
+	ToToken      string     `json:"toToken"`
+	FromAmount   string     `json:"fromAmount"`
+	ToAmount     string     `json:"toAmount"`
+	EstimatedGas string     `json:"estimatedGas"`
+	GasPrice     string     `json:"gasPrice"`
+	PriceImpact  float64    `json:"priceImpact"`
+	Fees         SwapFees   `json:"fees"`
+	Path         []string   `json:"path"`
+	Provider     string     `json:"provider"`
+	Dex          string     `json:"dex"`
+	Calldata     string     `json:"calldata"`
+	Value        string     `json:"value"`
+	Cost         *QuoteCost `json:"cost,omitempty"`
+}
+
+type SwapFees struct {
+	ProtocolFee string `json:"protocolFee"`
+	GasFee      string `json:"gasFee"`
+	Total       string `json:"total"`
+}
+
+// BridgeRouteRequest asks for routes moving a token between chains
+type BridgeRouteRequest struct {
+	FromChain   int     `json:"fromChain"`
+	ToChain     int     `json:"toChain"`
+	FromToken   string  `json:"fromToken"`
+	ToToken     string  `json:"toToken"`
+	FromAmount  string  `json:"fromAmount"`
+	UserAddress string  `json:"userAddress"`
+	Slippage    float64 `json:"slippage"`
+	MaxSlippage float64 `json:"maxSlippage,omitempty"`
+	// WantsDestinationGas asks for a refuel leg when the user holds no gas on the
+	// destination chain
+	WantsDestinationGas bool `json:"wantsDestinationGas,omitempty"`
+}
+
+// BridgeRoute is a bridge aggregator's route, with the steps taking it
+type BridgeRoute struct {
+	ID            string       `json:"id"`
+	FromChain     int          `json:"fromChain"`
+	ToChain       int          `json:"toChain"`
+	FromToken     string       `json:"fromToken"`
+	ToToken       string       `json:"toToken"`
+	FromAmount    string       `json:"fromAmount"`
+	ToAmount      string       `json:"toAmount"`
+	EstimatedGas  string       `json:"estimatedGas"`
+	EstimatedTime int          `json:"estimatedTime"`
+	Fees          BridgeFees   `json:"fees"`
+	Steps         []BridgeStep `json:"steps"`
+	Provider      string       `json:"provider"`
+	Relaxations   []string     `json:"relaxations,omitempty"`
+	Cost          *QuoteCost   `json:"cost,omitempty"`
+	// DestinationGas is set when the user would arrive without gas on the destination chain
+	DestinationGas *DestinationGas `json:"destinationGas,omitempty"`
+}
+
+// DestinationGas names the destination chain's gas token and, when one was asked for
+// and the provider has it, the refuel leg delivering some
+type DestinationGas struct {
+	Token  string     `json:"token"`
+	Symbol string     `json:"symbol"`
+	Refuel *RefuelLeg `json:"refuel,omitempty"`
+}
+
+type RefuelLeg struct {
+	Protocol   string `json:"protocol"`
+	FromToken  string `json:"fromToken"`
+	FromAmount string `json:"fromAmount"`
+	ToAmount   string `json:"toAmount"`
+}
+
+// QuoteCost is a route's fees priced the same way for every provider: in USD, in the
+// output token's base units, and fee by fee
+type QuoteCost struct {
+	TotalUSD     float64    `json:"totalUsd"`
+	Complete     bool       `json:"complete"`
+	TotalToToken string     `json:"totalToToken,omitempty"`
+	Breakdown    []QuoteFee `json:"breakdown"`
+}
+
+// QuoteFee is one fee of a route; Type is gas, protocol, bridge or integrator
+type QuoteFee struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Token       string   `json:"token"`
+	Symbol      string   `json:"symbol,omitempty"`
+	Amount      string   `json:"amount"`
+	AmountUSD   *float64 `json:"amountUsd"`
+}
+
+type BridgeFees struct {
+	BridgeFee string `json:"bridgeFee"`
+	GasFee    string `json:"gasFee"`
+	Total     string `json:"total"`
+}
+
+type BridgeStep struct {
+	Type       string `json:"type"`
+	Protocol   string `json:"protocol"`
+	FromChain  int    `json:"fromChain"`
+	ToChain    int    `json:"toChain"`
+	FromToken  string `json:"fromToken"`
+	ToToken    string `json:"toToken"`
+	FromAmount string `json:"fromAmount"`
+	ToAmount   string `json:"toAmount"`
+	Data       string `json:"data"`
+	Value      string `json:"value"`
+	GasLimit   string `json:"gasLimit"`
+}
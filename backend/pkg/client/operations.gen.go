@@ -0,0 +1,61 @@
+// Code generated by clientgen from spec/openapi.yaml. DO NOT EDIT.
+
+package client
+
+// Operations of the OpenAPI spec, by operationId
+var (
+	opCreateAlert          = operation{ID: "createAlert", Method: "POST", Path: "/alerts"}
+	opCreateWatchlist      = operation{ID: "createWatchlist", Method: "POST", Path: "/watchlists"}
+	opDeleteAlert          = operation{ID: "deleteAlert", Method: "DELETE", Path: "/alerts/{alertId}"}
+	opDeleteWatchlist      = operation{ID: "deleteWatchlist", Method: "DELETE", Path: "/watchlists/{watchlistId}"}
+	opExecuteSwap          = operation{ID: "executeSwap", Method: "POST", Path: "/swap/execute"}
+	opExportPnL            = operation{ID: "exportPnL", Method: "GET", Path: "/analytics/export/{address}"}
+	opGetAlert             = operation{ID: "getAlert", Method: "GET", Path: "/alerts/{alertId}"}
+	opGetAlerts            = operation{ID: "getAlerts", Method: "GET", Path: "/alerts"}
+	opGetBridgeRoutes      = operation{ID: "getBridgeRoutes", Method: "POST", Path: "/bridge/routes"}
+	opGetCurrentUser       = operation{ID: "getCurrentUser", Method: "GET", Path: "/auth/me"}
+	opGetPortfolioBalances = operation{ID: "getPortfolioBalances", Method: "GET", Path: "/portfolio/{address}/balances"}
+	opGetPortfolioHistory  = operation{ID: "getPortfolioHistory", Method: "GET", Path: "/portfolio/{address}/history"}
+	opGetSiweNonce         = operation{ID: "getSiweNonce", Method: "POST", Path: "/auth/siwe/nonce"}
+	opGetSwapQuote         = operation{ID: "getSwapQuote", Method: "POST", Path: "/swap/quote"}
+	opGetTokenApprovals    = operation{ID: "getTokenApprovals", Method: "GET", Path: "/transactions/{address}/approvals"}
+	opGetTransactions      = operation{ID: "getTransactions", Method: "GET", Path: "/transactions/{address}"}
+	opGetWatchlist         = operation{ID: "getWatchlist", Method: "GET", Path: "/watchlists/{watchlistId}"}
+	opGetWatchlists        = operation{ID: "getWatchlists", Method: "GET", Path: "/watchlists"}
+	opGetYieldPools        = operation{ID: "getYieldPools", Method: "GET", Path: "/yield/pools"}
+	opGetYieldPositions    = operation{ID: "getYieldPositions", Method: "GET", Path: "/yield/positions/{address}"}
+	opRevokeApproval       = operation{ID: "revokeApproval", Method: "DELETE", Path: "/transactions/{address}/approvals/{token}"}
+	opSendMagicLink        = operation{ID: "sendMagicLink", Method: "POST", Path: "/auth/magic-link"}
+	opUpdateAlert          = operation{ID: "updateAlert", Method: "PATCH", Path: "/alerts/{alertId}"}
+	opUpdateWatchlist      = operation{ID: "updateWatchlist", Method: "PUT", Path: "/watchlists/{watchlistId}"}
+	opVerifySiwe           = operation{ID: "verifySiwe", Method: "POST", Path: "/auth/siwe/verify"}
+)
+
+// operations lists every operation of the spec
+var operations = []operation{
+	opCreateAlert,
+	opCreateWatchlist,
+	opDeleteAlert,
+	opDeleteWatchlist,
+	opExecuteSwap,
+	opExportPnL,
+	opGetAlert,
+	opGetAlerts,
+	opGetBridgeRoutes,
+	opGetCurrentUser,
+	opGetPortfolioBalances,
+	opGetPortfolioHistory,
+	opGetSiweNonce,
+	opGetSwapQuote,
+	opGetTokenApprovals,
+	opGetTransactions,
+	opGetWatchlist,
+	opGetWatchlists,
+	opGetYieldPools,
+	opGetYieldPositions,
+	opRevokeApproval,
+	opSendMagicLink,
+	opUpdateAlert,
+	opUpdateWatchlist,
+	opVerifySiwe,
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// BalanceParams narrow GetBalances. The zero value reads the default chain's latest
+// balances.
+type BalanceParams struct {
+	ChainID   int
+	HideSmall bool
+	// Pinned reads every balance at one block, Block if set and the chain head otherwise
+	Pinned bool
+	Block  uint64
+}
+
+// HistoryParams narrow GetPortfolioHistory. Period is one of 1d, 1w, 1m, 3m, 1y and all;
+// Interval one of 1h, 1d and 1w. Empty ones take the API's defaults.
+type HistoryParams struct {
+	ChainID  int
+	Period   string
+	Interval string
+}
+
+// GetBalances returns address's balances
+func (c *Client) GetBalances(ctx context.Context, address string, params *BalanceParams) (*PortfolioBalances, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.ChainID != 0 {
+			query.Set("chainId", strconv.Itoa(params.ChainID))
+		}
+		if params.HideSmall {
+			query.Set("hideSmall", "true")
+		}
+		if params.Pinned {
+			query.Set("consistency", "pinned")
+		}
+		if params.Block != 0 {
+			query.Set("block", strconv.FormatUint(params.Block, 10))
+		}
+	}
+
+	var balances PortfolioBalances
+	if err := c.call(ctx, opGetPortfolioBalances, []string{address}, query, nil, &balances, nil); err != nil {
+		return nil, err
+	}
+	return &balances, nil
+}
+
+// GetPortfolioHistory returns address's value over time, oldest first
+func (c *Client) GetPortfolioHistory(ctx context.Context, address string, params *HistoryParams) ([]PortfolioHistoryPoint, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.ChainID != 0 {
+			query.Set("chainId", strconv.Itoa(params.ChainID))
+		}
+		if params.Period != "" {
+			query.Set("period", params.Period)
+		}
+		if params.Interval != "" {
+			query.Set("interval", params.Interval)
+		}
+	}
+
+	var data struct {
+		History []PortfolioHistoryPoint `json:"history"`
+	}
+	if err := c.call(ctx, opGetPortfolioHistory, []string{address}, query, nil, &data, nil); err != nil {
+		return nil, err
+	}
+	return data.History, nil
+}
\ No newline at end of file
@@ -0,0 +1,23 @@
+package client
+
+import "context"
+
+// GetSwapQuotes returns the aggregators' quotes for a swap, best first
+func (c *Client) GetSwapQuotes(ctx context.Context, req *SwapQuoteRequest) ([]SwapRoute, error) {
+	var routes []SwapRoute
+	if err := c.call(ctx, opGetSwapQuote, nil, nil, req, &routes, nil); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// GetBridgeRoutes returns the aggregators' routes for a transfer between chains
+func (c *Client) GetBridgeRoutes(ctx context.Context, req *BridgeRouteRequest) ([]BridgeRoute, error) {
+	var data struct {
+		Routes []BridgeRoute `json:"routes"`
+	}
+	if err := c.call(ctx, opGetBridgeRoutes, nil, nil, req, &data, nil); err != nil {
+		return nil, err
+	}
+	return data.Routes, nil
+}
\ No newline at end of file
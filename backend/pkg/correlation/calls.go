@@ -0,0 +1,103 @@
+package correlation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxHashedBody is how much of a response body its hash covers
+const maxHashedBody = 1 << 20
+
+// Call is an outbound request made through the transport, reported once its response
+// body is closed
+type Call struct {
+	RequestID string
+	Method    string
+	Host      string
+	Path      string
+	RawQuery  string
+	Status    int
+	Duration  time.Duration
+	// ResponseHash is the SHA-256 of the first megabyte of the body the caller read, so
+	// two calls can be told apart without keeping their responses
+	ResponseHash  string
+	ResponseBytes int64
+	Err           error
+	StartedAt     time.Time
+}
+
+var observer atomic.Pointer[func(Call)]
+
+// Observe has fn called with every call made through transports from NewTransport; nil
+// stops observing. fn runs on the caller's goroutine and mustn't block.
+func Observe(fn func(Call)) {
+	if fn == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&fn)
+}
+
+// observe runs the request and reports it to the observer, if there is one
+func observe(base http.RoundTripper, req *http.Request, requestID string) (*http.Response, error) {
+	fn := observer.Load()
+	if fn == nil {
+		return base.RoundTrip(req)
+	}
+
+	call := Call{
+		RequestID: requestID,
+		Method:    req.Method,
+		Host:      req.URL.Hostname(),
+		Path:      req.URL.Path,
+		RawQuery:  req.URL.RawQuery,
+		StartedAt: time.Now(),
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		call.Err = err
+		call.Duration = time.Since(call.StartedAt)
+		(*fn)(call)
+		return nil, err
+	}
+
+	call.Status = resp.StatusCode
+	resp.Body = &observedBody{ReadCloser: resp.Body, call: call, hash: sha256.New(), report: *fn}
+	return resp, nil
+}
+
+// observedBody hashes the body as it's read and reports the call when it's closed
+type observedBody struct {
+	io.ReadCloser
+	call   Call
+	hash   hash.Hash
+	report func(Call)
+	once   sync.Once
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if remaining := maxHashedBody - b.call.ResponseBytes; remaining > 0 {
+			b.hash.Write(p[:min(int64(n), remaining)])
+		}
+		b.call.ResponseBytes += int64(n)
+	}
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.call.Duration = time.Since(b.call.StartedAt)
+		b.call.ResponseHash = hex.EncodeToString(b.hash.Sum(nil))
+		b.report(b.call)
+	})
+	return err
+}
@@ -0,0 +1,87 @@
+// Package correlation carries the ID of the API request that started some work through
+// its context, so calls to providers and the worker made on its behalf can be traced
+// back to it.
+package correlation
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header carries the ID between services. The API reads it from clients through Fiber's
+// requestid middleware, which uses the same header.
+const Header = "X-Request-ID"
+
+// fiberLocalsKey is where Fiber's requestid middleware keeps the ID. Fiber locals are
+// values of the fasthttp context that handlers pass to services as c.Context().
+const fiberLocalsKey = "requestid"
+
+// maxIDLength bounds IDs forwarded to other services; clients may send their own
+const maxIDLength = 128
+
+type ctxKey struct{}
+
+// WithID returns a context carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID returns the request ID carried by ctx, or "" if there's none
+func ID(ctx context.Context) string {
+	if id, ok := ctx.Value(ctxKey{}).(string); ok {
+		return id
+	}
+	if id, ok := ctx.Value(fiberLocalsKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// Detach returns a background context carrying ctx's request ID, for work that outlives
+// the request. Fiber recycles a request's context once it's answered, so it mustn't be
+// kept or wrapped by such work.
+func Detach(ctx context.Context) context.Context {
+	if id := ID(ctx); id != "" {
+		return WithID(context.Background(), id)
+	}
+	return context.Background()
+}
+
+// transport sets the request ID header on outbound requests whose context carries one,
+// and reports them to the observer set with Observe
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base, or http.DefaultTransport if nil, to forward request IDs
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := ID(req.Context())
+	if !forwardable(id) || req.Header.Get(Header) != "" {
+		return observe(t.base, req, id)
+	}
+
+	// A RoundTripper mustn't modify the caller's request
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return observe(t.base, req, id)
+}
+
+// forwardable reports whether an ID is short printable ASCII, safe to pass on
+func forwardable(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package correlation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// localsContext stands in for the fasthttp context, which looks up string keys in its locals
+type localsContext struct {
+	context.Context
+	locals map[string]interface{}
+}
+
+func (c localsContext) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		return c.locals[k]
+	}
+	return c.Context.Value(key)
+}
+
+func TestID(t *testing.T) {
+	assert.Empty(t, ID(context.Background()))
+	assert.Equal(t, "req-1", ID(WithID(context.Background(), "req-1")))
+
+	fiberCtx := localsContext{Context: context.Background(), locals: map[string]interface{}{"requestid": "req-2"}}
+	assert.Equal(t, "req-2", ID(fiberCtx))
+
+	detached := Detach(fiberCtx)
+	assert.Equal(t, "req-2", ID(detached))
+	assert.Nil(t, detached.Done())
+}
+
+func TestTransport(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(Header))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	send := func(ctx context.Context, header string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(Header, header)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		if header == "" {
+			assert.Empty(t, req.Header.Get(Header), "the caller's request is left alone")
+		}
+	}
+
+	send(WithID(context.Background(), "req-1"), "")
+	send(context.Background(), "")
+	send(WithID(context.Background(), "req-1"), "explicit")
+	send(WithID(context.Background(), "has space"), "")
+	send(WithID(context.Background(), strings.Repeat("a", 200)), "")
+
+	assert.Equal(t, []string{"req-1", "", "explicit", "", ""}, got)
+}
+
+func TestObserve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(`{"price":1.01}`))
+	}))
+	defer server.Close()
+
+	var calls []Call
+	Observe(func(c Call) { calls = append(calls, c) })
+	defer Observe(nil)
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	req, err := http.NewRequestWithContext(WithID(context.Background(), "req-1"), http.MethodGet, server.URL+"/simple/price?ids=usd-coin", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	io.ReadAll(resp.Body)
+	assert.Empty(t, calls, "calls are reported once their body is closed")
+	resp.Body.Close()
+	resp.Body.Close()
+
+	require.Len(t, calls, 1)
+	sum := sha256.Sum256([]byte(`{"price":1.01}`))
+	assert.Equal(t, "req-1", calls[0].RequestID)
+	assert.Equal(t, http.MethodGet, calls[0].Method)
+	assert.Equal(t, "/simple/price", calls[0].Path)
+	assert.Equal(t, "ids=usd-coin", calls[0].RawQuery)
+	assert.Equal(t, http.StatusTeapot, calls[0].Status)
+	assert.Equal(t, hex.EncodeToString(sum[:]), calls[0].ResponseHash)
+	assert.Equal(t, int64(14), calls[0].ResponseBytes)
+
+	// Failed calls are reported straight away
+	server.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = client.Do(req)
+	require.Error(t, err)
+	require.Len(t, calls, 2)
+	assert.Error(t, calls[1].Err)
+	assert.Zero(t, calls[1].Status)
+}
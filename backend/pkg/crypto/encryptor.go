@@ -0,0 +1,282 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sealed values are envelopes: each value is encrypted with a fresh data key, and the
+// data key is wrapped with the master key. The envelope starts with envelopeMagic and
+// the ID of the master key, so the master key can be rotated by rewrapping data keys
+// alone. Values sealed before envelopes (nonce||ciphertext under the master key) still
+// open.
+var envelopeMagic = []byte("PPE1")
+
+const (
+	keyIDSize   = 4
+	dataKeySize = 32
+	// stringPrefix marks a sealed value stored as text, such as in a JSON column
+	stringPrefix = "enc:v1:"
+)
+
+// Encryptor seals small secrets (API keys, webhook URLs, exchange credentials) with
+// AES-256-GCM envelope encryption. It holds a primary master key that seals, and may
+// hold previous master keys that only open, for rotation.
+type Encryptor struct {
+	primary *masterKey
+	keys    map[string]*masterKey
+}
+
+type masterKey struct {
+	id   []byte
+	aead cipher.AEAD
+}
+
+// NewEncryptor creates an encryptor from a 32-byte key
+func NewEncryptor(key []byte) (*Encryptor, error) {
+	return NewKeyring(key)
+}
+
+// NewKeyring creates an encryptor sealing with primary and still opening values sealed
+// with any of the previous keys. All keys are 32 bytes.
+func NewKeyring(primary []byte, previous ...[]byte) (*Encryptor, error) {
+	e := &Encryptor{keys: make(map[string]*masterKey)}
+	for i, key := range append([][]byte{primary}, previous...) {
+		mk, err := newMasterKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			e.primary = mk
+		}
+		if _, ok := e.keys[string(mk.id)]; !ok {
+			e.keys[string(mk.id)] = mk
+		}
+	}
+	return e, nil
+}
+
+func newMasterKey(key []byte) (*masterKey, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &masterKey{id: sum[:keyIDSize], aead: aead}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// ParseKey decodes a 32-byte key given as hex or base64
+func ParseKey(encoded string) ([]byte, error) {
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("encryption key must be 32 bytes encoded as hex or base64")
+}
+
+// Encrypt seals plaintext under a fresh data key wrapped with the primary key
+func (e *Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := seal(dataAEAD, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return e.wrap(dataKey, body)
+}
+
+// wrap assembles an envelope around body, wrapping its data key with the primary key.
+// The header is authenticated with the wrapped key, so it can't be swapped.
+func (e *Encryptor) wrap(dataKey, body []byte) ([]byte, error) {
+	header := append(append([]byte{}, envelopeMagic...), e.primary.id...)
+	wrapped, err := seal(e.primary.aead, dataKey, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(header, wrapped...), body...), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any key the encryptor holds
+func (e *Encryptor) Decrypt(sealed []byte) ([]byte, error) {
+	if mk, wrapped, body, ok := e.parseEnvelope(sealed); ok {
+		dataKey, err := open(mk.aead, wrapped, sealed[:len(envelopeMagic)+keyIDSize])
+		if err != nil {
+			return nil, err
+		}
+		dataAEAD, err := newAEAD(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		return open(dataAEAD, body, nil)
+	}
+
+	// Values sealed before envelopes, directly under a master key
+	var lastErr error = errors.New("no encryption keys")
+	for _, mk := range e.orderedKeys() {
+		plaintext, err := open(mk.aead, sealed, nil)
+		if err == nil {
+			return plaintext, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Rotate returns sealed as it would be sealed now: envelopes of an older key get their
+// data key rewrapped with the primary key, and values from before envelopes are sealed
+// again. It reports false, returning sealed as is, when there's nothing to do.
+func (e *Encryptor) Rotate(sealed []byte) ([]byte, bool, error) {
+	if mk, wrapped, body, ok := e.parseEnvelope(sealed); ok {
+		if mk == e.primary {
+			return sealed, false, nil
+		}
+		dataKey, err := open(mk.aead, wrapped, sealed[:len(envelopeMagic)+keyIDSize])
+		if err != nil {
+			return nil, false, err
+		}
+		rotated, err := e.wrap(dataKey, body)
+		return rotated, err == nil, err
+	}
+
+	plaintext, err := e.Decrypt(sealed)
+	if err != nil {
+		return nil, false, err
+	}
+	rotated, err := e.Encrypt(plaintext)
+	return rotated, err == nil, err
+}
+
+// EncryptString seals a text value for a text or JSON column
+func (e *Encryptor) EncryptString(plaintext string) (string, error) {
+	sealed, err := e.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return stringPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString opens a value sealed by EncryptString. Values that aren't sealed,
+// stored before encryption was turned on, are returned as they are.
+func (e *Encryptor) DecryptString(value string) (string, error) {
+	if !IsEncryptedString(value) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, stringPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode sealed value: %w", err)
+	}
+	plaintext, err := e.Decrypt(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// RotateString is Rotate for values sealed by EncryptString; values that aren't sealed
+// yet are sealed
+func (e *Encryptor) RotateString(value string) (string, bool, error) {
+	if value == "" {
+		return value, false, nil
+	}
+	if !IsEncryptedString(value) {
+		sealed, err := e.EncryptString(value)
+		return sealed, err == nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, stringPrefix))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to decode sealed value: %w", err)
+	}
+	rotated, changed, err := e.Rotate(sealed)
+	if err != nil || !changed {
+		return value, false, err
+	}
+	return stringPrefix + base64.StdEncoding.EncodeToString(rotated), true, nil
+}
+
+// IsEncryptedString reports whether value was sealed by EncryptString
+func IsEncryptedString(value string) bool {
+	return strings.HasPrefix(value, stringPrefix)
+}
+
+// parseEnvelope splits an envelope sealed with one of the encryptor's keys into that
+// key, the wrapped data key and the sealed body
+func (e *Encryptor) parseEnvelope(sealed []byte) (*masterKey, []byte, []byte, bool) {
+	headerSize := len(envelopeMagic) + keyIDSize
+	wrappedSize := e.primary.aead.NonceSize() + dataKeySize + e.primary.aead.Overhead()
+	if len(sealed) < headerSize+wrappedSize || !bytes.HasPrefix(sealed, envelopeMagic) {
+		return nil, nil, nil, false
+	}
+	mk, ok := e.keys[string(sealed[len(envelopeMagic):headerSize])]
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return mk, sealed[headerSize : headerSize+wrappedSize], sealed[headerSize+wrappedSize:], true
+}
+
+// orderedKeys lists the primary key first, then the previous keys
+func (e *Encryptor) orderedKeys() []*masterKey {
+	keys := []*masterKey{e.primary}
+	for _, mk := range e.keys {
+		if mk != e.primary {
+			keys = append(keys, mk)
+		}
+	}
+	return keys
+}
+
+// seal encrypts plaintext and returns nonce||ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a value produced by seal
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,128 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptor_RoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	enc, err := NewEncryptor(key)
+	require.NoError(t, err)
+
+	sealed, err := enc.Encrypt([]byte("alchemy-secret"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "alchemy-secret")
+
+	plain, err := enc.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "alchemy-secret", string(plain))
+
+	// Tampering must be detected
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = enc.Decrypt(sealed)
+	assert.Error(t, err)
+}
+
+func TestNewEncryptor_InvalidKey(t *testing.T) {
+	_, err := NewEncryptor([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	raw := make([]byte, 32)
+	key, err := ParseKey(hex.EncodeToString(raw))
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+
+	_, err = ParseKey("not-a-key")
+	assert.Error(t, err)
+}
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, err := NewEncryptor(testKey(1))
+	require.NoError(t, err)
+	sealed, err := old.Encrypt([]byte("cex-secret"))
+	require.NoError(t, err)
+
+	// Values sealed before envelopes were nonce||ciphertext under the master key
+	legacy, err := seal(old.primary.aead, []byte("legacy-secret"), nil)
+	require.NoError(t, err)
+
+	rotated, err := NewKeyring(testKey(2), testKey(1))
+	require.NoError(t, err)
+	plain, err := rotated.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "cex-secret", string(plain))
+	plain, err = rotated.Decrypt(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-secret", string(plain))
+
+	resealed, changed, err := rotated.Rotate(sealed)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	// Rotating rewraps the data key and leaves the body alone
+	assert.Equal(t, sealed[len(sealed)-20:], resealed[len(resealed)-20:])
+	_, changed, err = rotated.Rotate(resealed)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	releg, changed, err := rotated.Rotate(legacy)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// Once rotated, the old key can go
+	current, err := NewEncryptor(testKey(2))
+	require.NoError(t, err)
+	for _, value := range [][]byte{resealed, releg} {
+		_, err := current.Decrypt(value)
+		assert.NoError(t, err)
+	}
+	_, err = current.Decrypt(sealed)
+	assert.Error(t, err)
+
+	// A swapped key ID in the header is caught
+	tampered := append([]byte{}, resealed...)
+	copy(tampered[len(envelopeMagic):], old.primary.id)
+	_, err = rotated.Decrypt(tampered)
+	assert.Error(t, err)
+}
+
+func TestEncryptor_Strings(t *testing.T) {
+	enc, err := NewEncryptor(testKey(3))
+	require.NoError(t, err)
+
+	sealed, err := enc.EncryptString("https://hooks.example.com/T0/B0/token")
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedString(sealed))
+	assert.NotContains(t, sealed, "token")
+
+	plain, err := enc.DecryptString(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/T0/B0/token", plain)
+
+	// Values stored before encryption was turned on read as they are, and get sealed on
+	// rotation
+	plain, err = enc.DecryptString("https://example.com/hook")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", plain)
+	rotated, changed, err := enc.RotateString("https://example.com/hook")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, IsEncryptedString(rotated))
+	_, changed, err = enc.RotateString(rotated)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
@@ -0,0 +1,345 @@
+// Package csvimport parses CSVs of manual transactions (OTC trades, gifts, mining and
+// other income) into rows, with user-chosen column mappings.
+package csvimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// MaxRows is the most data rows an import may have
+const MaxRows = 5000
+
+// Fields lists the import fields in display order
+var Fields = []string{
+	models.ImportFieldDate,
+	models.ImportFieldType,
+	models.ImportFieldAsset,
+	models.ImportFieldQuantity,
+	models.ImportFieldPriceUSD,
+	models.ImportFieldTotalUSD,
+	models.ImportFieldFeeUSD,
+	models.ImportFieldNotes,
+}
+
+// requiredFields must be mapped; a price comes from either price_usd or total_usd
+var requiredFields = []string{
+	models.ImportFieldDate,
+	models.ImportFieldType,
+	models.ImportFieldAsset,
+	models.ImportFieldQuantity,
+}
+
+// headerAliases are normalized column names recognized for each field
+var headerAliases = map[string][]string{
+	models.ImportFieldDate:     {"date", "datetime", "time", "timestamp", "dateutc", "executedat"},
+	models.ImportFieldType:     {"type", "kind", "transactiontype", "txtype", "side", "category"},
+	models.ImportFieldAsset:    {"asset", "token", "symbol", "currency", "coin", "tokenaddress"},
+	models.ImportFieldQuantity: {"quantity", "amount", "qty", "units", "size"},
+	models.ImportFieldPriceUSD: {"priceusd", "price", "unitprice", "usdprice", "rate"},
+	models.ImportFieldTotalUSD: {"totalusd", "total", "valueusd", "value", "costusd", "proceedsusd", "usdvalue"},
+	models.ImportFieldFeeUSD:   {"feeusd", "fee", "fees", "commission"},
+	models.ImportFieldNotes:    {"notes", "note", "description", "memo", "comment"},
+}
+
+// typeAliases maps normalized type values to manual transaction types
+var typeAliases = map[string]string{
+	"buy":           models.ManualTypeBuy,
+	"otcbuy":        models.ManualTypeBuy,
+	"purchase":      models.ManualTypeBuy,
+	"sell":          models.ManualTypeSell,
+	"otcsell":       models.ManualTypeSell,
+	"sale":          models.ManualTypeSell,
+	"giftin":        models.ManualTypeGiftIn,
+	"gift":          models.ManualTypeGiftIn,
+	"giftreceived":  models.ManualTypeGiftIn,
+	"giftout":       models.ManualTypeGiftOut,
+	"giftsent":      models.ManualTypeGiftOut,
+	"donation":      models.ManualTypeGiftOut,
+	"income":        models.ManualTypeIncome,
+	"mining":        models.ManualTypeIncome,
+	"miningincome":  models.ManualTypeIncome,
+	"reward":        models.ManualTypeIncome,
+	"staking":       models.ManualTypeIncome,
+	"stakingreward": models.ManualTypeIncome,
+	"airdrop":       models.ManualTypeIncome,
+	"interest":      models.ManualTypeIncome,
+	"cashback":      models.ManualTypeIncome,
+}
+
+// incomeCategories maps normalized income type values to their income category; other
+// income types are models.IncomeOther
+var incomeCategories = map[string]string{
+	"mining":        models.IncomeMining,
+	"miningincome":  models.IncomeMining,
+	"reward":        models.IncomeStakingReward,
+	"staking":       models.IncomeStakingReward,
+	"stakingreward": models.IncomeStakingReward,
+	"airdrop":       models.IncomeAirdrop,
+	"interest":      models.IncomeInterest,
+	"cashback":      models.IncomeCashback,
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+}
+
+// File is a parsed CSV: its header and data rows, with the line each row started on
+type File struct {
+	Columns []string
+	Rows    [][]string
+	Lines   []int
+}
+
+// Parse reads a CSV with a header row. Blank lines are skipped.
+func Parse(data []byte) (*File, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	file := &File{Columns: make([]string, len(header))}
+	seen := make(map[string]bool)
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q appears more than once", column)
+		}
+		seen[column] = true
+		file.Columns[i] = column
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(file.Rows) == MaxRows {
+			return nil, fmt.Errorf("file has more than %d rows", MaxRows)
+		}
+		line, _ := reader.FieldPos(0)
+		file.Rows = append(file.Rows, record)
+		file.Lines = append(file.Lines, line)
+	}
+
+	if len(file.Rows) == 0 {
+		return nil, fmt.Errorf("file has no data rows")
+	}
+	return file, nil
+}
+
+// SuggestMapping guesses which column holds each field from the column names
+func SuggestMapping(columns []string) map[string]string {
+	mapping := make(map[string]string)
+	used := make(map[string]bool)
+	for _, field := range Fields {
+		for _, alias := range headerAliases[field] {
+			for _, column := range columns {
+				if !used[column] && normalize(column) == alias {
+					mapping[field] = column
+					used[column] = true
+					break
+				}
+			}
+			if _, ok := mapping[field]; ok {
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+// ValidateMapping checks that mapping names known fields and existing columns and
+// covers every required field. Fields mapped to "" are left unmapped.
+func ValidateMapping(mapping map[string]string, columns []string) error {
+	exists := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		exists[column] = true
+	}
+
+	for field, column := range mapping {
+		if _, ok := headerAliases[field]; !ok {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		if column != "" && !exists[column] {
+			return fmt.Errorf("column %q for %s is not in the file", column, field)
+		}
+	}
+	for _, field := range requiredFields {
+		if mapping[field] == "" {
+			return fmt.Errorf("a column must be mapped to %s", field)
+		}
+	}
+	return nil
+}
+
+// Records parses every row with mapping, which must have passed ValidateMapping.
+// Problems are reported on each row rather than stopping the parse.
+func (f *File) Records(mapping map[string]string) []models.TransactionImportRow {
+	index := make(map[string]int, len(mapping))
+	for i, column := range f.Columns {
+		for field, mapped := range mapping {
+			if mapped != "" && mapped == column {
+				index[field] = i
+			}
+		}
+	}
+
+	rows := make([]models.TransactionImportRow, 0, len(f.Rows))
+	for i, record := range f.Rows {
+		value := func(field string) string {
+			if col, ok := index[field]; ok && col < len(record) {
+				return strings.TrimSpace(record[col])
+			}
+			return ""
+		}
+		rows = append(rows, parseRow(f.Lines[i], value))
+	}
+	return rows
+}
+
+func parseRow(number int, value func(string) string) models.TransactionImportRow {
+	row := models.TransactionImportRow{
+		Row:   number,
+		Asset: value(models.ImportFieldAsset),
+		Notes: value(models.ImportFieldNotes),
+	}
+	fail := func(format string, args ...interface{}) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+
+	if ts, err := parseDate(value(models.ImportFieldDate)); err != nil {
+		fail("%v", err)
+	} else if ts.After(time.Now()) {
+		fail("date %s is in the future", ts.Format(time.RFC3339))
+	} else {
+		row.Timestamp = ts
+	}
+
+	rawType := value(models.ImportFieldType)
+	if t, ok := typeAliases[normalize(rawType)]; ok {
+		row.Type = t
+		if t == models.ManualTypeIncome {
+			row.IncomeCategory = models.IncomeOther
+			if category, ok := incomeCategories[normalize(rawType)]; ok {
+				row.IncomeCategory = category
+			}
+		}
+	} else {
+		fail("unknown type %q (use buy, sell, gift_in, gift_out or income)", rawType)
+	}
+
+	if row.Asset == "" {
+		fail("asset is required")
+	}
+
+	quantity, err := parseAmount(value(models.ImportFieldQuantity))
+	switch {
+	case err != nil:
+		fail("quantity: %v", err)
+	case quantity <= 0:
+		fail("quantity must be positive")
+	default:
+		row.Quantity = quantity
+	}
+
+	fee, err := parseAmount(value(models.ImportFieldFeeUSD))
+	if err != nil || fee < 0 {
+		fail("fee_usd must be a non-negative number")
+	} else {
+		row.FeeUSD = fee
+	}
+
+	// The unit price is given directly or derived from the total. Gifts may omit it,
+	// giving a zero cost basis or proceeds.
+	price, err := parseAmount(value(models.ImportFieldPriceUSD))
+	if err != nil || price < 0 {
+		fail("price_usd must be a non-negative number")
+	}
+	if price == 0 && err == nil {
+		total, err := parseAmount(value(models.ImportFieldTotalUSD))
+		if err != nil || total < 0 {
+			fail("total_usd must be a non-negative number")
+		} else if total > 0 && row.Quantity > 0 {
+			price = total / row.Quantity
+		}
+	}
+	row.PriceUSD = price
+	if price == 0 && (row.Type == models.ManualTypeBuy || row.Type == models.ManualTypeSell || row.Type == models.ManualTypeIncome) {
+		fail("a price_usd or total_usd is required for %s", row.Type)
+	}
+
+	return row
+}
+
+// parseDate accepts common date layouts and Unix timestamps in seconds, in UTC unless
+// the value carries a zone
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("date is required")
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q (use YYYY-MM-DD, RFC 3339 or MM/DD/YYYY)", s)
+}
+
+// parseAmount parses a number, ignoring currency symbols and thousands separators.
+// An empty value is zero.
+func parseAmount(s string) (float64, error) {
+	s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// normalize lowercases s and drops everything but letters and digits, so "Price (USD)"
+// and "price_usd" compare equal
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package csvimport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = "\xef\xbb\xbfDate,Type,Token,Amount,Price (USD),Total USD,Fee,Memo\n" +
+	"2024-01-15,OTC Buy,ETH,2,\"$2,500.00\",,10,desk trade\n" +
+	"\n" +
+	"2024-02-01 12:30:00,Mining,BTC,0.01,,450,,\n" +
+	"1706745600,gift received,USDC,100,,,,from a friend\n" +
+	"2024-13-01,swap,,-1,abc,,,\n"
+
+func TestParseAndRecords(t *testing.T) {
+	file, err := Parse([]byte(sample))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Date", "Type", "Token", "Amount", "Price (USD)", "Total USD", "Fee", "Memo"}, file.Columns)
+	require.Len(t, file.Rows, 4, "blank lines are skipped")
+
+	mapping := SuggestMapping(file.Columns)
+	assert.Equal(t, map[string]string{
+		models.ImportFieldDate:     "Date",
+		models.ImportFieldType:     "Type",
+		models.ImportFieldAsset:    "Token",
+		models.ImportFieldQuantity: "Amount",
+		models.ImportFieldPriceUSD: "Price (USD)",
+		models.ImportFieldTotalUSD: "Total USD",
+		models.ImportFieldFeeUSD:   "Fee",
+		models.ImportFieldNotes:    "Memo",
+	}, mapping)
+	require.NoError(t, ValidateMapping(mapping, file.Columns))
+
+	rows := file.Records(mapping)
+	require.Len(t, rows, 4)
+
+	assert.Empty(t, rows[0].Errors)
+	assert.Equal(t, 2, rows[0].Row)
+	assert.Equal(t, models.ManualTypeBuy, rows[0].Type)
+	assert.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), rows[0].Timestamp)
+	assert.Equal(t, 2500.0, rows[0].PriceUSD)
+	assert.Equal(t, 10.0, rows[0].FeeUSD)
+	assert.Equal(t, "desk trade", rows[0].Notes)
+
+	assert.Empty(t, rows[1].Errors)
+	assert.Equal(t, models.ManualTypeIncome, rows[1].Type)
+	assert.Equal(t, models.IncomeMining, rows[1].IncomeCategory)
+	assert.InDelta(t, 45000.0, rows[1].PriceUSD, 1e-9, "price is derived from the total")
+
+	assert.Empty(t, rows[2].Errors, "gifts may omit a price")
+	assert.Equal(t, models.ManualTypeGiftIn, rows[2].Type)
+	assert.Empty(t, rows[2].IncomeCategory)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), rows[2].Timestamp)
+
+	assert.Len(t, rows[3].Errors, 5)
+	assert.Equal(t, 6, rows[3].Row)
+}
+
+func TestValidateMapping(t *testing.T) {
+	columns := []string{"Date", "Type", "Asset", "Quantity"}
+
+	assert.NoError(t, ValidateMapping(SuggestMapping(columns), columns))
+	assert.Error(t, ValidateMapping(map[string]string{"date": "Date"}, columns))
+	assert.Error(t, ValidateMapping(map[string]string{"date": "When", "type": "Type", "asset": "Asset", "quantity": "Quantity"}, columns))
+	assert.Error(t, ValidateMapping(map[string]string{"colour": "Date"}, columns))
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse(nil)
+	assert.Error(t, err)
+
+	_, err = Parse([]byte("Date,Type\n"))
+	assert.Error(t, err, "no data rows")
+
+	_, err = Parse([]byte("Date,Date\n2024-01-01,2024-01-02\n"))
+	assert.Error(t, err, "duplicate columns")
+
+	_, err = Parse([]byte("Date,\n2024-01-01,x\n"))
+	assert.Error(t, err, "unnamed column")
+}
@@ -0,0 +1,209 @@
+// Package dbtrace times database queries. Durations are kept as histograms per calling
+// function, published with expvar, and queries slower than a threshold are logged with
+// their normalized SQL and caller so slow repo methods can be found.
+package dbtrace
+
+import (
+	"context"
+	"expvar"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/jackc/pgx/v5"
+)
+
+// modulePrefix is trimmed from caller names
+const modulePrefix = "github.com/defi-dashboard/backend/"
+
+// maxLoggedSQL bounds the SQL written to slow query logs
+const maxLoggedSQL = 1000
+
+// bucketBounds are the histogram upper bounds, in milliseconds
+var bucketBounds = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// Tracer is a pgx.QueryTracer recording query durations
+type Tracer struct {
+	slowThreshold time.Duration
+	clock         clock.Clock
+
+	mu      sync.Mutex
+	callers map[string]*histogram
+}
+
+// NewTracer creates a tracer logging queries that take at least slowThreshold; zero
+// turns the log off
+func NewTracer(slowThreshold time.Duration) *Tracer {
+	return &Tracer{
+		slowThreshold: slowThreshold,
+		clock:         clock.System,
+		callers:       make(map[string]*histogram),
+	}
+}
+
+// Publish exposes the histograms as the expvar variable name
+func (t *Tracer) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} { return t.Snapshot() }))
+}
+
+type traceKey struct{}
+
+type queryStart struct {
+	sql   string
+	start time.Time
+}
+
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, traceKey{}, queryStart{sql: data.SQL, start: t.clock.Now()})
+}
+
+// TraceQueryEnd runs inside the call that ran the query, or that closed its rows, so the
+// caller is still on the stack
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	started, ok := ctx.Value(traceKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := t.clock.Now().Sub(started.start)
+	caller := callerName()
+	sql := NormalizeSQL(started.sql)
+
+	t.histogram(caller).observe(elapsed, sql)
+
+	if t.slowThreshold > 0 && elapsed >= t.slowThreshold {
+		if len(sql) > maxLoggedSQL {
+			sql = sql[:maxLoggedSQL] + "..."
+		}
+		fields := []interface{}{"caller", caller, "duration_ms", elapsed.Milliseconds(), "sql", sql,
+			"request_id", correlation.ID(ctx)}
+		if data.Err != nil {
+			fields = append(fields, "error", data.Err)
+		}
+		logger.Warn("Slow database query", fields...)
+	}
+}
+
+func (t *Tracer) histogram(caller string) *histogram {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.callers[caller]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(bucketBounds)+1)}
+		t.callers[caller] = h
+	}
+	return h
+}
+
+// CallerStats is one caller's query durations. Buckets are cumulative, keyed by their
+// upper bound in milliseconds, like Prometheus histograms.
+type CallerStats struct {
+	Count   uint64            `json:"count"`
+	SumMs   float64           `json:"sum_ms"`
+	Buckets map[string]uint64 `json:"buckets"`
+	// SQL is the caller's most recent query
+	SQL string `json:"sql"`
+}
+
+// Snapshot returns the histograms keyed by caller
+func (t *Tracer) Snapshot() map[string]CallerStats {
+	t.mu.Lock()
+	callers := make(map[string]*histogram, len(t.callers))
+	for caller, h := range t.callers {
+		callers[caller] = h
+	}
+	t.mu.Unlock()
+
+	out := make(map[string]CallerStats, len(callers))
+	for caller, h := range callers {
+		out[caller] = h.stats()
+	}
+	return out
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sumMs  float64
+	sql    string
+}
+
+func (h *histogram) observe(d time.Duration, sql string) {
+	ms := float64(d) / float64(time.Millisecond)
+	i := 0
+	for i < len(bucketBounds) && ms > bucketBounds[i] {
+		i++
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.count++
+	h.sumMs += ms
+	h.sql = sql
+}
+
+func (h *histogram) stats() CallerStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make(map[string]uint64, len(h.counts))
+	var cumulative uint64
+	for i, n := range h.counts {
+		cumulative += n
+		bound := "+Inf"
+		if i < len(bucketBounds) {
+			bound = strconv.FormatFloat(bucketBounds[i], 'f', -1, 64)
+		}
+		buckets[bound] = cumulative
+	}
+	return CallerStats{Count: h.count, SumMs: h.sumMs, Buckets: buckets, SQL: h.sql}
+}
+
+// callerName returns the first function on the stack outside pgx and the tracer, normally
+// the repo method that ran the query
+func callerName() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		fn := frame.Function
+		if fn != "" && !strings.HasPrefix(fn, "github.com/jackc/") &&
+			!strings.HasSuffix(frame.File, "/pkg/dbtrace/tracer.go") && !strings.HasPrefix(fn, "runtime.") {
+			return strings.TrimPrefix(fn, modulePrefix)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+var (
+	whitespace     = regexp.MustCompile(`\s+`)
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	placeholder    = regexp.MustCompile(`\$\d+`)
+	placeholders   = regexp.MustCompile(`\$\?(?:\s*,\s*\$\?)+`)
+	valueRows      = regexp.MustCompile(`\(\$\?(?:, \.\.\.)?\)(?:\s*,\s*\(\$\?(?:, \.\.\.)?\))+`)
+	lineComment    = regexp.MustCompile(`--[^\n]*`)
+)
+
+// NormalizeSQL reduces a query to its shape: literals become ?, placeholders $?, and
+// placeholder lists built for IN clauses or bulk inserts collapse to one, so the same
+// query with different arguments normalizes the same
+func NormalizeSQL(sql string) string {
+	sql = lineComment.ReplaceAllString(sql, "")
+	sql = stringLiteral.ReplaceAllString(sql, "?")
+	sql = placeholder.ReplaceAllString(sql, "$?")
+	sql = numericLiteral.ReplaceAllString(sql, "?")
+	sql = placeholders.ReplaceAllString(sql, "$?, ...")
+	sql = valueRows.ReplaceAllString(sql, "($?, ...), ...")
+	return strings.TrimSpace(whitespace.ReplaceAllString(sql, " "))
+}
@@ -0,0 +1,58 @@
+package dbtrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defi-dashboard/backend/pkg/clock"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM wallets\n\tWHERE user_id = $1 AND chain_id = 137 -- owner's wallets\n": "SELECT * FROM wallets WHERE user_id = $? AND chain_id = ?",
+		"SELECT id FROM tokens WHERE symbol = 'O''Brien' AND address IN ($2, $3, $4)":         "SELECT id FROM tokens WHERE symbol = ? AND address IN ($?, ...)",
+		"INSERT INTO prices (token_id, price) VALUES ($1, $2), ($3, $4), ($5, $6)":            "INSERT INTO prices (token_id, price) VALUES ($?, ...), ...",
+		"SELECT apy::float8 FROM yield_pools LIMIT 20 OFFSET 40":                              "SELECT apy::float8 FROM yield_pools LIMIT ? OFFSET ?",
+	}
+	for sql, want := range cases {
+		assert.Equal(t, want, NormalizeSQL(sql), sql)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tracer := NewTracer(100 * time.Millisecond)
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	tracer.clock = clk
+
+	run := func(d time.Duration, err error) {
+		ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1 FROM alerts WHERE id = $1"})
+		clk.Advance(d)
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: err})
+	}
+	run(3*time.Millisecond, nil)
+	run(40*time.Millisecond, nil)
+	run(150*time.Millisecond, errors.New("canceling statement due to statement timeout"))
+
+	// A query ending without a traced start is ignored
+	tracer.TraceQueryEnd(context.Background(), nil, pgx.TraceQueryEndData{})
+
+	snapshot := tracer.Snapshot()
+	require.Len(t, snapshot, 1)
+	for caller, stats := range snapshot {
+		assert.Contains(t, caller, "dbtrace.TestTracer")
+		assert.Equal(t, uint64(3), stats.Count)
+		assert.InDelta(t, 193, stats.SumMs, 0.001)
+		assert.Equal(t, uint64(0), stats.Buckets["1"])
+		assert.Equal(t, uint64(1), stats.Buckets["5"])
+		assert.Equal(t, uint64(2), stats.Buckets["50"])
+		assert.Equal(t, uint64(3), stats.Buckets["250"])
+		assert.Equal(t, uint64(3), stats.Buckets["+Inf"])
+		assert.Equal(t, "SELECT ? FROM alerts WHERE id = $?", stats.SQL)
+	}
+}
@@ -0,0 +1,357 @@
+// Package decimal provides an exact decimal number for money and rates. Sums of USD
+// values and rates kept as Decimal don't pick up the binary rounding error float64 does.
+package decimal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionPrecision is the number of decimal places quotients are rounded to
+const DivisionPrecision = 18
+
+var ten = big.NewInt(10)
+
+// Decimal is an exact decimal number: coef × 10^-scale. The zero value is 0. Decimals
+// are immutable; every operation returns a new one.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// Zero is the decimal 0
+var Zero = Decimal{}
+
+// NewFromInt returns n as a decimal
+func NewFromInt(n int64) Decimal {
+	return Decimal{coef: big.NewInt(n)}
+}
+
+// New returns coef × 10^-scale
+func New(coef int64, scale int32) Decimal {
+	return Decimal{coef: big.NewInt(coef)}.withScale(scale)
+}
+
+// NewFromBigInt returns coef × 10^-scale, such as a raw token balance and its decimals
+func NewFromBigInt(coef *big.Int, scale int32) Decimal {
+	if coef == nil {
+		return Zero
+	}
+	return Decimal{coef: new(big.Int).Set(coef)}.withScale(scale)
+}
+
+// NewFromFloat returns the shortest decimal that reads back as f. NaN and infinities
+// are not numbers and return 0.
+func NewFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Zero
+	}
+	d, _ := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// NewFromFloatPtr returns f as a decimal pointer, nil when f is nil
+func NewFromFloatPtr(f *float64) *Decimal {
+	if f == nil {
+		return nil
+	}
+	d := NewFromFloat(*f)
+	return &d
+}
+
+// Parse reads a decimal such as "-12.50" or "1.5e-3"
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Zero, fmt.Errorf("invalid decimal %q", s)
+		}
+		mantissa = s[:i]
+	}
+
+	digits, fraction := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits, fraction = mantissa[:i], mantissa[i+1:]
+	}
+	sign := ""
+	if digits != "" && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if digits == "" && fraction == "" {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, r := range digits + fraction {
+		if r < '0' || r > '9' {
+			return Zero, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+
+	coef, ok := new(big.Int).SetString(sign+digits+fraction, 10)
+	if !ok {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+	scale := int64(len(fraction)) - exp
+	if scale > math.MaxInt32 || scale < math.MinInt32 {
+		return Zero, fmt.Errorf("decimal %q out of range", s)
+	}
+	if scale < 0 {
+		coef.Mul(coef, new(big.Int).Exp(ten, big.NewInt(-scale), nil))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) value() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescaleTo returns d with at least scale decimal places, without changing its value
+func (d Decimal) rescaleTo(scale int32) Decimal {
+	if scale <= d.scale {
+		return Decimal{coef: d.value(), scale: d.scale}
+	}
+	factor := new(big.Int).Exp(ten, big.NewInt(int64(scale-d.scale)), nil)
+	return Decimal{coef: new(big.Int).Mul(d.value(), factor), scale: scale}
+}
+
+// withScale reinterprets an integer decimal as coef × 10^-scale
+func (d Decimal) withScale(scale int32) Decimal {
+	if scale < 0 {
+		factor := new(big.Int).Exp(ten, big.NewInt(int64(-scale)), nil)
+		return Decimal{coef: new(big.Int).Mul(d.value(), factor)}
+	}
+	return Decimal{coef: d.value(), scale: scale}
+}
+
+// align returns a and b at a common scale
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescaleTo(scale).value(), b.rescaleTo(scale).value(), scale
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{coef: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{coef: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns d × other
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.value(), other.value()), scale: d.scale + other.scale}
+}
+
+// Div returns d ÷ other rounded to DivisionPrecision places. It panics when dividing by
+// zero, as integer division does.
+func (d Decimal) Div(other Decimal) Decimal {
+	return d.DivRound(other, DivisionPrecision)
+}
+
+// DivRound returns d ÷ other rounded half away from zero to places decimal places
+func (d Decimal) DivRound(other Decimal, places int32) Decimal {
+	if other.IsZero() {
+		panic("decimal division by zero")
+	}
+	// d/other = (a × 10^-sa) / (b × 10^-sb); scale the numerator so the quotient has one
+	// guard digit beyond places
+	shift := int64(places) + 1 + int64(other.scale) - int64(d.scale)
+	num := new(big.Int).Set(d.value())
+	den := new(big.Int).Set(other.value())
+	if shift >= 0 {
+		num.Mul(num, new(big.Int).Exp(ten, big.NewInt(shift), nil))
+	} else {
+		den.Mul(den, new(big.Int).Exp(ten, big.NewInt(-shift), nil))
+	}
+	quotient := new(big.Int).Quo(num, den)
+	return Decimal{coef: quotient, scale: places + 1}.Round(places)
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+// Abs returns |d|
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.value()), scale: d.scale}
+}
+
+// Round returns d rounded half away from zero to places decimal places
+func (d Decimal) Round(places int32) Decimal {
+	if places >= d.scale {
+		return d.rescaleTo(places)
+	}
+	factor := new(big.Int).Exp(ten, big.NewInt(int64(d.scale-places)), nil)
+	quotient, remainder := new(big.Int).QuoRem(d.value(), factor, new(big.Int))
+	// Round away from zero when the dropped digits are at least half
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(factor) >= 0 {
+		if d.value().Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return Decimal{coef: quotient, scale: places}
+}
+
+// Sign returns -1, 0 or 1 as d is negative, zero or positive
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp returns -1, 0 or 1 as d is less than, equal to or greater than other
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and other have the same value
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// LessThan reports whether d < other
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.Cmp(other) < 0
+}
+
+// GreaterThan reports whether d > other
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.Cmp(other) > 0
+}
+
+// Float64 returns the nearest float64, for display and ratios where exactness isn't needed
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String formats d in plain notation without trailing zeros, e.g. "1234.5"
+func (d Decimal) String() string {
+	s := d.StringFixed(d.scale)
+	if strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed formats d rounded to exactly places decimal places, e.g. "1234.50"
+func (d Decimal) StringFixed(places int32) string {
+	if places < 0 {
+		places = 0
+	}
+	r := d.Round(places)
+	digits := new(big.Int).Abs(r.value()).String()
+	if places > 0 {
+		if len(digits) <= int(places) {
+			digits = strings.Repeat("0", int(places)-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-int(places)] + "." + digits[len(digits)-int(places):]
+	}
+	if r.Sign() < 0 {
+		digits = "-" + digits
+	}
+	return digits
+}
+
+// Sum returns the total of values
+func Sum(values ...Decimal) Decimal {
+	total := Zero
+	for _, v := range values {
+		total = total.Add(v)
+	}
+	return total
+}
+
+// MarshalJSON writes d as a JSON number, so fields that were float64 keep their shape
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or a numeric string
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column. NULL reads as 0; use a *Decimal field for nullable columns.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case int64:
+		*d = NewFromInt(v)
+	case float64:
+		*d = NewFromFloat(v)
+	default:
+		return fmt.Errorf("cannot scan %T into decimal", src)
+	}
+	return nil
+}
+
+// Value writes d to a NUMERIC column
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
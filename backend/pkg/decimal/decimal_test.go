@@ -0,0 +1,88 @@
+package decimal
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndString(t *testing.T) {
+	cases := map[string]string{
+		"0":                                   "0",
+		"12.50":                               "12.5",
+		"-0.001":                              "-0.001",
+		"+7":                                  "7",
+		".5":                                  "0.5",
+		"1.5e3":                               "1500",
+		"2.5E-4":                              "0.00025",
+		"1234567890123456789012345.123456789": "1234567890123456789012345.123456789",
+	}
+	for in, want := range cases {
+		d, err := Parse(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, d.String(), in)
+	}
+
+	for _, in := range []string{"", "abc", "1.2.3", "-", "1e", "0x10"} {
+		_, err := Parse(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	// 0.1 summed ten times drifts in float64 but not here
+	total := Zero
+	for i := 0; i < 10; i++ {
+		total = total.Add(MustParse("0.1"))
+	}
+	assert.True(t, total.Equal(NewFromInt(1)))
+
+	assert.Equal(t, "0.3", NewFromFloat(0.1).Add(NewFromFloat(0.2)).String())
+	assert.Equal(t, "-1.25", MustParse("1.5").Sub(MustParse("2.75")).String())
+	assert.Equal(t, "3.0625", MustParse("1.75").Mul(MustParse("1.75")).String())
+	assert.Equal(t, "0.333333333333333333", NewFromInt(1).Div(NewFromInt(3)).String())
+	assert.Equal(t, "-0.67", NewFromInt(-2).DivRound(NewFromInt(3), 2).String())
+	assert.Equal(t, "12.35", MustParse("12.345").Round(2).String())
+	assert.Equal(t, "-12.35", MustParse("-12.345").Round(2).String())
+	assert.Equal(t, "12.30", MustParse("12.3").StringFixed(2))
+	assert.Equal(t, "0.05", MustParse("0.05").StringFixed(2))
+	assert.Equal(t, "1.5", Sum(New(5, 1), New(1, 0)).String())
+	assert.Equal(t, "1200", New(12, -2).String())
+	assert.Equal(t, "1.5", NewFromBigInt(big.NewInt(1500000), 6).String())
+	assert.True(t, NewFromBigInt(nil, 18).IsZero())
+	assert.Equal(t, 1, MustParse("1.01").Cmp(NewFromInt(1)))
+	assert.Equal(t, 2.5, MustParse("2.50").Float64())
+	assert.Panics(t, func() { NewFromInt(1).Div(Zero) })
+}
+
+func TestJSONAndSQL(t *testing.T) {
+	type holding struct {
+		ValueUSD Decimal  `json:"value_usd"`
+		APY      *Decimal `json:"apy,omitempty"`
+	}
+
+	out, err := json.Marshal(holding{ValueUSD: MustParse("1234.5600")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value_usd":1234.56}`, string(out))
+
+	var in holding
+	require.NoError(t, json.Unmarshal([]byte(`{"value_usd":"10.01","apy":4.25}`), &in))
+	assert.Equal(t, "10.01", in.ValueUSD.String())
+	assert.Equal(t, "4.25", in.APY.String())
+
+	var scanned Decimal
+	require.NoError(t, scanned.Scan("99.9900000000"))
+	assert.Equal(t, "99.99", scanned.String())
+	require.NoError(t, scanned.Scan([]byte("-1")))
+	assert.Equal(t, "-1", scanned.String())
+	require.NoError(t, scanned.Scan(nil))
+	assert.True(t, scanned.IsZero())
+	assert.Error(t, scanned.Scan(true))
+
+	value, err := MustParse("0.10").Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", value)
+}
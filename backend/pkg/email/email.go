@@ -0,0 +1,123 @@
+// Package email sends transactional email through a configurable provider and reads the
+// bounce and complaint notifications providers post back
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Email providers
+const (
+	ProviderLog      = "log"
+	ProviderSES      = "ses"
+	ProviderSendGrid = "sendgrid"
+	ProviderSMTP     = "smtp"
+)
+
+// Message is one email to one recipient. Text is required; HTML is sent alongside it as
+// an alternative when set.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Tag is passed to providers that support it, so their delivery events can be
+	// traced back to the message
+	Tag string
+}
+
+// Sender delivers messages through one provider
+type Sender interface {
+	// Provider names the provider, as one of the Provider* values
+	Provider() string
+	// Send returns the provider's ID for the message, which its bounce and complaint
+	// notifications refer to. Errors the provider won't get over by retrying are
+	// *PermanentError.
+	Send(ctx context.Context, msg *Message) (string, error)
+}
+
+// PermanentError is a send the provider rejected outright, such as a malformed or
+// unverified address. Retrying it can't succeed.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// IsPermanent reports whether retrying err can't succeed
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// statusError turns a provider's HTTP error status into an error. Client errors other
+// than throttling are permanent.
+func statusError(provider string, status int, body []byte) error {
+	err := fmt.Errorf("%s returned status %d: %s", provider, status, string(body))
+	if status >= 400 && status < 500 && status != 408 && status != 429 {
+		return &PermanentError{Err: err}
+	}
+	return err
+}
+
+// Config selects and configures a provider
+type Config struct {
+	Provider string
+	// From is the sender address, optionally with a display name
+	From string
+
+	// SES sends through the SESv2 API with static credentials
+	SESRegion          string
+	SESAccessKeyID     string
+	SESSecretAccessKey string
+	SESSessionToken    string
+
+	SendGridAPIKey string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// New builds the sender for cfg.Provider
+func New(cfg Config) (Sender, error) {
+	if cfg.Provider != "" && cfg.Provider != ProviderLog && cfg.From == "" {
+		return nil, fmt.Errorf("a from address is required for %s email", cfg.Provider)
+	}
+
+	switch cfg.Provider {
+	case "", ProviderLog:
+		return LogSender{}, nil
+	case ProviderSES:
+		if cfg.SESRegion == "" || cfg.SESAccessKeyID == "" || cfg.SESSecretAccessKey == "" {
+			return nil, fmt.Errorf("a region and credentials are required for ses email")
+		}
+		endpoint := fmt.Sprintf("https://email.%s.amazonaws.com", cfg.SESRegion)
+		return NewSESSender(endpoint, cfg.SESRegion, cfg.SESAccessKeyID, cfg.SESSecretAccessKey, cfg.SESSessionToken, cfg.From), nil
+	case ProviderSendGrid:
+		if cfg.SendGridAPIKey == "" {
+			return nil, fmt.Errorf("an API key is required for sendgrid email")
+		}
+		return NewSendGridSender("https://api.sendgrid.com", cfg.SendGridAPIKey, cfg.From), nil
+	case ProviderSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("a host is required for smtp email")
+		}
+		port := cfg.SMTPPort
+		if port == 0 {
+			port = 587
+		}
+		return NewSMTPSender(cfg.SMTPHost, port, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+// httpTimeout bounds each call to an HTTP email API
+const httpTimeout = 15 * time.Second
@@ -0,0 +1,230 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	sender, err := New(Config{})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderLog, sender.Provider())
+
+	_, err = New(Config{Provider: ProviderSendGrid, SendGridAPIKey: "key"})
+	assert.Error(t, err, "from is required")
+	_, err = New(Config{Provider: ProviderSES, From: "alerts@example.org"})
+	assert.Error(t, err, "credentials are required")
+	_, err = New(Config{Provider: "pigeon", From: "alerts@example.org"})
+	assert.Error(t, err)
+
+	sender, err = New(Config{Provider: ProviderSMTP, From: "alerts@example.org", SMTPHost: "smtp.example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, 587, sender.(*SMTPSender).port)
+}
+
+func TestSESSender(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, sesSendPath, r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/ses/aws4_request")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"MessageId":"ses-123"}`))
+	}))
+	defer server.Close()
+
+	sender := NewSESSender(server.URL, "eu-west-1", "AKID", "secret", "", "alerts@example.org")
+	id, err := sender.Send(context.Background(), &Message{To: "user@example.com", Subject: "Hi", Text: "Hello", Tag: "d1"})
+	require.NoError(t, err)
+	assert.Equal(t, "ses-123", id)
+	assert.Equal(t, "alerts@example.org", got["FromEmailAddress"])
+	assert.Equal(t, []interface{}{"user@example.com"}, got["Destination"].(map[string]interface{})["ToAddresses"])
+	body := got["Content"].(map[string]interface{})["Simple"].(map[string]interface{})["Body"].(map[string]interface{})
+	assert.NotContains(t, body, "Html", "no HTML part without HTML")
+}
+
+func TestSendGridSender(t *testing.T) {
+	status := http.StatusAccepted
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		got = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("X-Message-Id", "sgid1")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := NewSendGridSender(server.URL, "key", "Portfolio Pilot <alerts@example.org>")
+	msg := &Message{To: "user@example.com", Subject: "Hi", Text: "Hello", HTML: "<p>Hello</p>", Tag: "d1"}
+	id, err := sender.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, "sgid1", id)
+	assert.Equal(t, map[string]interface{}{"email": "alerts@example.org", "name": "Portfolio Pilot"}, got["from"])
+	assert.Len(t, got["content"], 2)
+	assert.Equal(t, map[string]interface{}{"tag": "d1"}, got["custom_args"])
+
+	status = http.StatusBadRequest
+	_, err = sender.Send(context.Background(), msg)
+	assert.True(t, IsPermanent(err), "rejected requests aren't retried")
+
+	for _, status = range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		_, err = sender.Send(context.Background(), msg)
+		require.Error(t, err)
+		assert.False(t, IsPermanent(err), "status %d is worth retrying", status)
+	}
+}
+
+func TestBuildMIME(t *testing.T) {
+	from := &mail.Address{Name: "Portfolio Pilot", Address: "alerts@example.org"}
+	to := &mail.Address{Address: "user@example.com"}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := buildMIME(from, to, "id@example.org", &Message{Subject: "Prix élevé", Text: "Hello"}, now)
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Prix élevé", subject)
+	assert.Equal(t, "<id@example.org>", parsed.Header.Get("Message-ID"))
+	body, _ := io.ReadAll(parsed.Body)
+	assert.Equal(t, "Hello", string(body))
+
+	data, err = buildMIME(from, to, "id@example.org", &Message{Subject: "Hi", Text: "Hello", HTML: "<p>Hello</p>"}, now)
+	require.NoError(t, err)
+	parsed, err = mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative"))
+	body, _ = io.ReadAll(parsed.Body)
+	assert.Contains(t, string(body), "text/plain")
+	assert.Contains(t, string(body), "<p>Hello</p>")
+}
+
+func TestRender(t *testing.T) {
+	msg, err := Render("alert", map[string]interface{}{
+		"Headline":    "Alert triggered: price above on ETH",
+		"Details":     []string{"price: 4000", "threshold: <3900>"},
+		"TriggeredAt": time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alert triggered: price above on ETH", msg.Subject)
+	assert.Contains(t, msg.Text, "threshold: <3900>")
+	assert.Contains(t, msg.Text, "2026-10-01 12:00 UTC")
+	assert.Contains(t, msg.HTML, "threshold: &lt;3900&gt;", "HTML is escaped")
+
+	msg, err = Render("statement", map[string]interface{}{
+		"Month":         "2026-09",
+		"TotalValueUSD": "1234.56",
+		"DownloadURL":   "https://files.example.org/s?sig=a&b=c",
+		"LinkValidity":  "7 days",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Your 2026-09 portfolio statement is ready", msg.Subject)
+	assert.Contains(t, msg.Text, "https://files.example.org/s?sig=a&b=c")
+	assert.Contains(t, msg.HTML, `href="https://files.example.org/s?sig=a&amp;b=c"`)
+
+	msg, err = Render("pool_migration", map[string]interface{}{
+		"PoolName":      "USDC Supply v2",
+		"Chain":         "Ethereum",
+		"SuccessorName": "USDC Supply v3",
+		"SuccessorAPY":  "",
+		"SuccessorURL":  "https://api.example.org/api/v1/yield/pools/1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "USDC Supply v2 has moved to USDC Supply v3", msg.Subject)
+	assert.NotContains(t, msg.Text, "APY", "pools without an APY don't quote one")
+	assert.Contains(t, msg.HTML, `href="https://api.example.org/api/v1/yield/pools/1"`)
+
+	msg, err = Render("broadcast", map[string]interface{}{
+		"Title":   "",
+		"Message": "Withdraw from <Acme> pools now",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "A message from Portfolio Pilot", msg.Subject, "untitled broadcasts get a generic subject")
+	assert.Contains(t, msg.HTML, "Withdraw from &lt;Acme&gt; pools now")
+
+	msg, err = Render("webhook_report_failed", map[string]interface{}{
+		"Name":      "Daily <summary>",
+		"Failures":  3,
+		"LastError": "notification endpoint returned status 503",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Your webhook report Daily <summary> is failing", msg.Subject)
+	assert.Contains(t, msg.Text, "status 503")
+	assert.Contains(t, msg.HTML, "Daily &lt;summary&gt;")
+
+	_, err = Render("missing", nil)
+	assert.Error(t, err)
+}
+
+func TestParseSESNotification(t *testing.T) {
+	body, err := os.ReadFile("testdata/ses_bounce.json")
+	require.NoError(t, err)
+	events, subscribeURL, err := ParseSESNotification(body)
+	require.NoError(t, err)
+	assert.Empty(t, subscribeURL)
+	require.Len(t, events, 1)
+	assert.Equal(t, Event{
+		Type:      EventBounce,
+		Email:     "gone@example.com",
+		MessageID: "0100017f-ses-message",
+		Permanent: true,
+		Detail:    "Permanent/General: smtp; 550 5.1.1 user unknown",
+	}, events[0])
+
+	body, err = os.ReadFile("testdata/ses_complaint.json")
+	require.NoError(t, err)
+	events, _, err = ParseSESNotification(body)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventComplaint, events[0].Type)
+	assert.Equal(t, "annoyed@example.com", events[0].Email)
+	assert.Equal(t, "abuse", events[0].Detail)
+
+	events, subscribeURL, err = ParseSESNotification([]byte(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription", subscribeURL)
+
+	_, _, err = ParseSESNotification([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestParseSendGridEvents(t *testing.T) {
+	body, err := os.ReadFile("testdata/sendgrid_events.json")
+	require.NoError(t, err)
+	events, err := ParseSendGridEvents(body)
+	require.NoError(t, err)
+
+	type summary struct {
+		Type      string
+		Email     string
+		MessageID string
+		Permanent bool
+	}
+	var got []summary
+	for _, e := range events {
+		got = append(got, summary{e.Type, e.Email, e.MessageID, e.Permanent})
+	}
+	assert.Equal(t, []summary{
+		{EventDelivery, "ok@example.com", "sgid1", false},
+		{EventBounce, "gone@example.com", "sgid2", true},
+		{EventBounce, "busy@example.com", "sgid3", false},
+		{EventBounce, "old@example.com", "sgid4", true},
+		{EventComplaint, "annoyed@example.com", "sgid6", false},
+	}, got)
+}
@@ -0,0 +1,174 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Delivery event types
+const (
+	EventDelivery  = "delivery"
+	EventBounce    = "bounce"
+	EventComplaint = "complaint"
+)
+
+// Event is what a provider reported about a message sent to one recipient
+type Event struct {
+	Type  string
+	Email string
+	// MessageID is the provider's ID for the message, as returned by Sender.Send
+	MessageID string
+	// Permanent is set for hard bounces: the address doesn't exist or won't ever accept
+	// mail. Soft bounces such as a full mailbox may succeed later.
+	Permanent bool
+	Detail    string
+}
+
+// snsEnvelope is how Amazon SNS posts to HTTP subscribers
+type snsEnvelope struct {
+	Type         string `json:"Type"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+type sesNotification struct {
+	// Identity notifications set notificationType and configuration set event
+	// publishing sets eventType
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Mail             struct {
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce struct {
+		BounceType        string `json:"bounceType"`
+		BounceSubType     string `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Delivery struct {
+		Recipients []string `json:"recipients"`
+	} `json:"delivery"`
+}
+
+// ParseSESNotification reads an SES notification delivered through SNS. When SNS is
+// confirming the subscription instead, it returns the URL to visit to confirm it and no
+// events.
+func ParseSESNotification(body []byte) ([]Event, string, error) {
+	var envelope snsEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, "", fmt.Errorf("invalid sns message: %w", err)
+	}
+	switch envelope.Type {
+	case "SubscriptionConfirmation":
+		return nil, envelope.SubscribeURL, nil
+	case "Notification":
+	default:
+		return nil, "", nil
+	}
+
+	var n sesNotification
+	if err := json.Unmarshal([]byte(envelope.Message), &n); err != nil {
+		return nil, "", fmt.Errorf("invalid ses notification: %w", err)
+	}
+	kind := n.NotificationType
+	if kind == "" {
+		kind = n.EventType
+	}
+
+	var events []Event
+	switch kind {
+	case "Bounce":
+		detail := n.Bounce.BounceType
+		if n.Bounce.BounceSubType != "" {
+			detail += "/" + n.Bounce.BounceSubType
+		}
+		for _, r := range n.Bounce.BouncedRecipients {
+			d := detail
+			if r.DiagnosticCode != "" {
+				d += ": " + r.DiagnosticCode
+			}
+			events = append(events, Event{
+				Type:      EventBounce,
+				Email:     r.EmailAddress,
+				MessageID: n.Mail.MessageID,
+				Permanent: n.Bounce.BounceType == "Permanent",
+				Detail:    d,
+			})
+		}
+	case "Complaint":
+		for _, r := range n.Complaint.ComplainedRecipients {
+			events = append(events, Event{
+				Type:      EventComplaint,
+				Email:     r.EmailAddress,
+				MessageID: n.Mail.MessageID,
+				Detail:    n.Complaint.ComplaintFeedbackType,
+			})
+		}
+	case "Delivery":
+		for _, email := range n.Delivery.Recipients {
+			events = append(events, Event{Type: EventDelivery, Email: email, MessageID: n.Mail.MessageID})
+		}
+	}
+	return events, "", nil
+}
+
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Event       string `json:"event"`
+	SGMessageID string `json:"sg_message_id"`
+	Type        string `json:"type"`
+	Reason      string `json:"reason"`
+}
+
+// sendGridHardDrops are the reasons SendGrid drops a message for an address that is
+// already known not to accept mail
+var sendGridHardDrops = []string{"Bounced Address", "Invalid", "Spam Reporting Address"}
+
+// ParseSendGridEvents reads a batch posted by SendGrid's event webhook, keeping the
+// delivery, bounce and complaint events
+func ParseSendGridEvents(body []byte) ([]Event, error) {
+	var batch []sendGridEvent
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, fmt.Errorf("invalid sendgrid events: %w", err)
+	}
+
+	var events []Event
+	for _, e := range batch {
+		// Event message IDs add a per-recipient suffix to the ID returned on send
+		messageID, _, _ := strings.Cut(e.SGMessageID, ".")
+		event := Event{Email: e.Email, MessageID: messageID, Detail: e.Reason}
+		switch e.Event {
+		case "delivered":
+			event.Type = EventDelivery
+		case "bounce":
+			// "blocked" bounces are the receiving server refusing for now
+			event.Type = EventBounce
+			event.Permanent = e.Type != "blocked"
+		case "dropped":
+			hard := false
+			for _, reason := range sendGridHardDrops {
+				hard = hard || strings.HasPrefix(e.Reason, reason)
+			}
+			if !hard {
+				continue
+			}
+			event.Type = EventBounce
+			event.Permanent = true
+		case "spamreport":
+			event.Type = EventComplaint
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
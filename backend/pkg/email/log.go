@@ -0,0 +1,19 @@
+package email
+
+import (
+	"context"
+
+	"github.com/defi-dashboard/backend/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// LogSender logs emails instead of sending them, for environments without a provider
+type LogSender struct{}
+
+func (LogSender) Provider() string { return ProviderLog }
+
+func (LogSender) Send(ctx context.Context, msg *Message) (string, error) {
+	id := uuid.NewString()
+	logger.Info("Email", "to", msg.To, "subject", msg.Subject, "tag", msg.Tag, "messageID", id)
+	return id, nil
+}
@@ -0,0 +1,81 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+)
+
+// SendGridSender sends through SendGrid's v3 mail send API
+type SendGridSender struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	from       string
+}
+
+func NewSendGridSender(baseURL, apiKey, from string) *SendGridSender {
+	return &SendGridSender{
+		httpClient: &http.Client{Timeout: httpTimeout},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		from:       from,
+	}
+}
+
+func (s *SendGridSender) Provider() string { return ProviderSendGrid }
+
+func (s *SendGridSender) Send(ctx context.Context, msg *Message) (string, error) {
+	from := map[string]string{"email": s.from}
+	if addr, err := mail.ParseAddress(s.from); err == nil {
+		from = map[string]string{"email": addr.Address}
+		if addr.Name != "" {
+			from["name"] = addr.Name
+		}
+	}
+	content := []map[string]string{{"type": "text/plain", "value": msg.Text}}
+	if msg.HTML != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": msg.HTML})
+	}
+	request := map[string]interface{}{
+		"personalizations": []map[string]interface{}{{
+			"to": []map[string]string{{"email": msg.To}},
+		}},
+		"from":    from,
+		"subject": msg.Subject,
+		"content": content,
+	}
+	if msg.Tag != "" {
+		// Custom args come back on every event webhook call about the message
+		request["custom_args"] = map[string]string{"tag": msg.Tag}
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", statusError("sendgrid", resp.StatusCode, data)
+	}
+	// Events carry the ID with a suffix per recipient, e.g. "<id>.filter0001..."
+	return resp.Header.Get("X-Message-Id"), nil
+}
@@ -0,0 +1,148 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sesSendPath is the SESv2 SendEmail operation
+const sesSendPath = "/v2/email/outbound-emails"
+
+// SESSender sends through Amazon SES's v2 API. Requests are signed with SigV4 using
+// static credentials.
+type SESSender struct {
+	httpClient      *http.Client
+	endpoint        string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	from            string
+}
+
+func NewSESSender(endpoint, region, accessKeyID, secretAccessKey, sessionToken, from string) *SESSender {
+	return &SESSender{
+		httpClient:      &http.Client{Timeout: httpTimeout},
+		endpoint:        strings.TrimRight(endpoint, "/"),
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		from:            from,
+	}
+}
+
+func (s *SESSender) Provider() string { return ProviderSES }
+
+func (s *SESSender) Send(ctx context.Context, msg *Message) (string, error) {
+	body := map[string]interface{}{
+		"Text": map[string]string{"Data": msg.Text, "Charset": "UTF-8"},
+	}
+	if msg.HTML != "" {
+		body["Html"] = map[string]string{"Data": msg.HTML, "Charset": "UTF-8"}
+	}
+	request := map[string]interface{}{
+		"FromEmailAddress": s.from,
+		"Destination":      map[string][]string{"ToAddresses": {msg.To}},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{
+				"Subject": map[string]string{"Data": msg.Subject, "Charset": "UTF-8"},
+				"Body":    body,
+			},
+		},
+	}
+	if msg.Tag != "" {
+		request["EmailTags"] = []map[string]string{{"Name": "tag", "Value": msg.Tag}}
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint+sesSendPath, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := s.sign(req, payload, time.Now().UTC()); err != nil {
+		return "", err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ses request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", statusError("ses", resp.StatusCode, data)
+	}
+
+	var result struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode ses response: %w", err)
+	}
+	return result.MessageID, nil
+}
+
+// sign adds SigV4 headers for the ses service
+func (s *SESSender) sign(req *http.Request, payload []byte, now time.Time) error {
+	u, err := url.Parse(s.endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + u.Host + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signedHeaders := "content-type;host;x-amz-date"
+	if s.sessionToken != "" {
+		canonicalHeaders += "x-amz-security-token:" + s.sessionToken + "\n"
+		signedHeaders += ";x-amz-security-token"
+	}
+
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := "POST\n" + sesSendPath + "\n\n" + canonicalHeaders + "\n" + signedHeaders + "\n" + hex.EncodeToString(payloadHash[:])
+
+	scope := date + "/" + s.region + "/ses/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature,
+	))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,134 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	BeaconchainAPIBase = "https://beaconcha.in/api/v1"
+	// BeaconchainRateLimit is the free tier's per-minute limit; keyed plans allow more
+	BeaconchainRateLimit = 10
+	// BeaconchainMaxValidators is how many validators one request may ask for
+	BeaconchainMaxValidators = 100
+)
+
+type BeaconchainClient struct {
+	httpClient  *http.Client
+	apiKey      string
+	rateLimiter *RateLimiter
+}
+
+func NewBeaconchainClient(apiKey string) *BeaconchainClient {
+	return &BeaconchainClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		apiKey:      apiKey,
+		rateLimiter: NewRateLimiter(BeaconchainRateLimit, time.Minute),
+	}
+}
+
+// BeaconValidator is a validator's beacon chain state; amounts are in gwei
+type BeaconValidator struct {
+	Index            int64  `json:"validatorindex"`
+	Pubkey           string `json:"pubkey"`
+	Status           string `json:"status"`
+	Balance          int64  `json:"balance"`
+	EffectiveBalance int64  `json:"effectivebalance"`
+}
+
+// BeaconValidatorPerformance is a validator's consensus rewards in gwei
+type BeaconValidatorPerformance struct {
+	Index            int64 `json:"validatorindex"`
+	Performance1d    int64 `json:"performance1d"`
+	Performance7d    int64 `json:"performance7d"`
+	Performance31d   int64 `json:"performance31d"`
+	Performance365d  int64 `json:"performance365d"`
+	PerformanceTotal int64 `json:"performancetotal"`
+}
+
+// GetValidators fetches up to BeaconchainMaxValidators validators by index
+func (c *BeaconchainClient) GetValidators(ctx context.Context, indices []int64) ([]BeaconValidator, error) {
+	var validators []BeaconValidator
+	if err := c.getValidatorData(ctx, indices, "", &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// GetValidatorPerformance fetches lifetime and trailing rewards for up to
+// BeaconchainMaxValidators validators by index
+func (c *BeaconchainClient) GetValidatorPerformance(ctx context.Context, indices []int64) ([]BeaconValidatorPerformance, error) {
+	var performance []BeaconValidatorPerformance
+	if err := c.getValidatorData(ctx, indices, "/performance", &performance); err != nil {
+		return nil, err
+	}
+	return performance, nil
+}
+
+// getValidatorData requests /validator/{indices}{suffix}. beaconcha.in returns a bare
+// object instead of an array when only one validator is requested.
+func (c *BeaconchainClient) getValidatorData(ctx context.Context, indices []int64, suffix string, out interface{}) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	if len(indices) > BeaconchainMaxValidators {
+		return fmt.Errorf("at most %d validators per request", BeaconchainMaxValidators)
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	ids := make([]string, len(indices))
+	for i, index := range indices {
+		ids[i] = strconv.FormatInt(index, 10)
+	}
+	endpoint := fmt.Sprintf("%s/validator/%s%s", BeaconchainAPIBase, strings.Join(ids, ","), suffix)
+	if c.apiKey != "" {
+		endpoint += "?apikey=" + url.QueryEscape(c.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("beaconcha.in API error: %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return err
+	}
+	if response.Status != "OK" {
+		return fmt.Errorf("beaconcha.in API error: %s", response.Status)
+	}
+
+	data := bytes.TrimSpace(response.Data)
+	if len(data) > 0 && data[0] == '{' {
+		data = append(append([]byte{'['}, data...), ']')
+	}
+	return json.Unmarshal(data, out)
+}
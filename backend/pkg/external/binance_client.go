@@ -0,0 +1,260 @@
+package external
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	BinanceAPIBase = "https://api.binance.com"
+	// BinanceRateLimit stays well inside the 6000 request weight per minute; a trade
+	// history page weighs 20
+	BinanceRateLimit = 200
+	// binanceTradePageSize is the most trades myTrades returns per call
+	binanceTradePageSize = 1000
+)
+
+// binanceQuoteAssets are the quote assets searched for a held asset's trading pairs.
+// myTrades is per symbol, so pairs against other quotes aren't discovered.
+var binanceQuoteAssets = []string{"USDT", "USDC", "FDUSD", "BUSD", "BTC", "ETH", "BNB", "EUR"}
+
+// Binance limits are per IP and key, so all connectors share one limiter
+var binanceRateLimiter = NewRateLimiter(BinanceRateLimit, time.Minute)
+
+type binanceClient struct {
+	httpClient *http.Client
+	creds      ExchangeCredentials
+}
+
+func newBinanceClient(creds ExchangeCredentials) *binanceClient {
+	return &binanceClient{
+		httpClient: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		creds: creds,
+	}
+}
+
+type binanceSymbol struct {
+	Symbol     string `json:"symbol"`
+	BaseAsset  string `json:"baseAsset"`
+	QuoteAsset string `json:"quoteAsset"`
+}
+
+type binanceTrade struct {
+	ID              int64  `json:"id"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	Time            int64  `json:"time"` // Unix milliseconds
+	IsBuyer         bool   `json:"isBuyer"`
+}
+
+func (c *binanceClient) GetBalances(ctx context.Context) ([]ExchangeBalance, error) {
+	var account struct {
+		Balances []struct {
+			Asset  string `json:"asset"`
+			Free   string `json:"free"`
+			Locked string `json:"locked"`
+		} `json:"balances"`
+	}
+	if err := c.signedGet(ctx, "/api/v3/account", url.Values{"omitZeroBalances": {"true"}}, &account); err != nil {
+		return nil, err
+	}
+
+	balances := make([]ExchangeBalance, 0, len(account.Balances))
+	for _, b := range account.Balances {
+		free, _ := strconv.ParseFloat(b.Free, 64)
+		locked, _ := strconv.ParseFloat(b.Locked, 64)
+		if free+locked == 0 {
+			continue
+		}
+		balances = append(balances, ExchangeBalance{
+			Asset:  normalizeExchangeAsset(b.Asset),
+			Free:   free,
+			Locked: locked,
+		})
+	}
+	return balances, nil
+}
+
+// GetTrades reads the trades of every pair between a held asset and a common quote asset
+func (c *binanceClient) GetTrades(ctx context.Context, since time.Time) ([]ExchangeFill, error) {
+	balances, err := c.GetBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := c.getSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fills []ExchangeFill
+	for _, balance := range balances {
+		for _, quote := range binanceQuoteAssets {
+			symbol, ok := symbols[balance.Asset+quote]
+			if !ok {
+				continue
+			}
+			trades, err := c.getSymbolTrades(ctx, symbol, since)
+			if err != nil {
+				return nil, err
+			}
+			fills = append(fills, trades...)
+		}
+	}
+
+	sort.SliceStable(fills, func(i, j int) bool {
+		return fills[i].ExecutedAt.Before(fills[j].ExecutedAt)
+	})
+	return fills, nil
+}
+
+// getSymbolTrades pages through a symbol's trades. A zero since reads from the first trade
+// by id; otherwise 24 hour windows, the longest myTrades accepts, are read up to now.
+func (c *binanceClient) getSymbolTrades(ctx context.Context, symbol binanceSymbol, since time.Time) ([]ExchangeFill, error) {
+	var fills []ExchangeFill
+	collect := func(page []binanceTrade) {
+		for _, t := range page {
+			fills = append(fills, symbol.fill(t))
+		}
+	}
+
+	if since.IsZero() {
+		fromID := int64(0)
+		for {
+			var page []binanceTrade
+			params := url.Values{
+				"symbol": {symbol.Symbol},
+				"fromId": {strconv.FormatInt(fromID, 10)},
+				"limit":  {strconv.Itoa(binanceTradePageSize)},
+			}
+			if err := c.signedGet(ctx, "/api/v3/myTrades", params, &page); err != nil {
+				return nil, err
+			}
+			collect(page)
+			if len(page) < binanceTradePageSize {
+				return fills, nil
+			}
+			fromID = page[len(page)-1].ID + 1
+		}
+	}
+
+	now := time.Now()
+	for window := since; window.Before(now); window = window.Add(24 * time.Hour) {
+		start, end := window, window.Add(24*time.Hour-time.Millisecond)
+		for {
+			var page []binanceTrade
+			params := url.Values{
+				"symbol":    {symbol.Symbol},
+				"startTime": {strconv.FormatInt(start.UnixMilli(), 10)},
+				"endTime":   {strconv.FormatInt(end.UnixMilli(), 10)},
+				"limit":     {strconv.Itoa(binanceTradePageSize)},
+			}
+			if err := c.signedGet(ctx, "/api/v3/myTrades", params, &page); err != nil {
+				return nil, err
+			}
+			collect(page)
+			if len(page) < binanceTradePageSize {
+				break
+			}
+			start = time.UnixMilli(page[len(page)-1].Time + 1)
+		}
+	}
+	return fills, nil
+}
+
+func (s binanceSymbol) fill(t binanceTrade) ExchangeFill {
+	price, _ := strconv.ParseFloat(t.Price, 64)
+	qty, _ := strconv.ParseFloat(t.Qty, 64)
+	fee, _ := strconv.ParseFloat(t.Commission, 64)
+	side := "sell"
+	if t.IsBuyer {
+		side = "buy"
+	}
+	return ExchangeFill{
+		TradeID:    fmt.Sprintf("%s-%d", s.Symbol, t.ID),
+		BaseAsset:  normalizeExchangeAsset(s.BaseAsset),
+		QuoteAsset: normalizeExchangeAsset(s.QuoteAsset),
+		Side:       side,
+		Quantity:   qty,
+		Price:      price,
+		Fee:        fee,
+		FeeAsset:   normalizeExchangeAsset(t.CommissionAsset),
+		ExecutedAt: time.UnixMilli(t.Time).UTC(),
+	}
+}
+
+// getSymbols returns the exchange's spot symbols by name
+func (c *binanceClient) getSymbols(ctx context.Context) (map[string]binanceSymbol, error) {
+	var info struct {
+		Symbols []binanceSymbol `json:"symbols"`
+	}
+	if err := c.get(ctx, "/api/v3/exchangeInfo", url.Values{"permissions": {"SPOT"}}, nil, &info); err != nil {
+		return nil, err
+	}
+
+	symbols := make(map[string]binanceSymbol, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		symbols[symbol.Symbol] = symbol
+	}
+	return symbols, nil
+}
+
+// signedGet sends an authenticated request: the query string is signed with
+// HMAC-SHA256 of the API secret
+func (c *binanceClient) signedGet(ctx context.Context, path string, params url.Values, out interface{}) error {
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	mac := hmac.New(sha256.New, []byte(c.creds.APISecret))
+	mac.Write([]byte(params.Encode()))
+	params.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	return c.get(ctx, path, params, http.Header{"X-MBX-APIKEY": {c.creds.APIKey}}, out)
+}
+
+func (c *binanceClient) get(ctx context.Context, path string, params url.Values, header http.Header, out interface{}) error {
+	if err := binanceRateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", BinanceAPIBase+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Msg != "" {
+			return fmt.Errorf("Binance API error: %d %s", resp.StatusCode, apiErr.Msg)
+		}
+		return fmt.Errorf("Binance API error: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
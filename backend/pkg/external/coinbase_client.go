@@ -0,0 +1,255 @@
+package external
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	CoinbaseAPIHost   = "api.coinbase.com"
+	CoinbaseRateLimit = 600 // private endpoints allow 30 requests per second
+	// coinbasePageSize is the most accounts or fills requested per page
+	coinbasePageSize = 250
+)
+
+var coinbaseRateLimiter = NewRateLimiter(CoinbaseRateLimit, time.Minute)
+
+// coinbaseClient reads the Advanced Trade API, authenticating each request with a short
+// lived ES256 JWT signed by the CDP API key
+type coinbaseClient struct {
+	httpClient *http.Client
+	keyName    string
+	privateKey *ecdsa.PrivateKey
+}
+
+func newCoinbaseClient(creds ExchangeCredentials) (*coinbaseClient, error) {
+	// Secrets pasted from the key file often keep their escaped newlines
+	secret := strings.ReplaceAll(creds.APISecret, `\n`, "\n")
+	block, _ := pem.Decode([]byte(secret))
+	if block == nil {
+		return nil, fmt.Errorf("api secret is not a PEM encoded private key")
+	}
+
+	var key *ecdsa.PrivateKey
+	if parsed, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		key = parsed
+	} else if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("api secret is not an EC private key")
+		}
+		key = ecKey
+	} else {
+		return nil, fmt.Errorf("failed to parse api secret: %w", err)
+	}
+
+	return &coinbaseClient{
+		httpClient: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		keyName:    creds.APIKey,
+		privateKey: key,
+	}, nil
+}
+
+type coinbaseAmount struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+type coinbaseFill struct {
+	TradeID     string `json:"trade_id"`
+	EntryID     string `json:"entry_id"`
+	TradeTime   string `json:"trade_time"`
+	Price       string `json:"price"`
+	Size        string `json:"size"`
+	Commission  string `json:"commission"`
+	ProductID   string `json:"product_id"` // BASE-QUOTE
+	Side        string `json:"side"`
+	SizeInQuote bool   `json:"size_in_quote"`
+}
+
+func (c *coinbaseClient) GetBalances(ctx context.Context) ([]ExchangeBalance, error) {
+	var balances []ExchangeBalance
+	cursor := ""
+	for {
+		var page struct {
+			Accounts []struct {
+				Currency         string         `json:"currency"`
+				AvailableBalance coinbaseAmount `json:"available_balance"`
+				Hold             coinbaseAmount `json:"hold"`
+			} `json:"accounts"`
+			HasNext bool   `json:"has_next"`
+			Cursor  string `json:"cursor"`
+		}
+		params := url.Values{"limit": {strconv.Itoa(coinbasePageSize)}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		if err := c.get(ctx, "/api/v3/brokerage/accounts", params, &page); err != nil {
+			return nil, err
+		}
+
+		for _, account := range page.Accounts {
+			free, _ := strconv.ParseFloat(account.AvailableBalance.Value, 64)
+			locked, _ := strconv.ParseFloat(account.Hold.Value, 64)
+			if free+locked == 0 {
+				continue
+			}
+			balances = append(balances, ExchangeBalance{
+				Asset:  normalizeExchangeAsset(account.Currency),
+				Free:   free,
+				Locked: locked,
+			})
+		}
+
+		if !page.HasNext || page.Cursor == "" {
+			return balances, nil
+		}
+		cursor = page.Cursor
+	}
+}
+
+func (c *coinbaseClient) GetTrades(ctx context.Context, since time.Time) ([]ExchangeFill, error) {
+	var fills []ExchangeFill
+	cursor := ""
+	for {
+		var page struct {
+			Fills  []coinbaseFill `json:"fills"`
+			Cursor string         `json:"cursor"`
+		}
+		params := url.Values{"limit": {strconv.Itoa(coinbasePageSize)}}
+		if !since.IsZero() {
+			params.Set("start_sequence_timestamp", since.UTC().Format(time.RFC3339))
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		if err := c.get(ctx, "/api/v3/brokerage/orders/historical/fills", params, &page); err != nil {
+			return nil, err
+		}
+
+		for _, f := range page.Fills {
+			fill, ok := f.fill()
+			if ok {
+				fills = append(fills, fill)
+			}
+		}
+
+		if page.Cursor == "" || len(page.Fills) < coinbasePageSize {
+			break
+		}
+		cursor = page.Cursor
+	}
+
+	sort.SliceStable(fills, func(i, j int) bool {
+		return fills[i].ExecutedAt.Before(fills[j].ExecutedAt)
+	})
+	return fills, nil
+}
+
+// fill converts a Coinbase fill; commissions are charged in the quote currency
+func (f coinbaseFill) fill() (ExchangeFill, bool) {
+	base, quote, ok := strings.Cut(f.ProductID, "-")
+	if !ok {
+		return ExchangeFill{}, false
+	}
+	executedAt, err := time.Parse(time.RFC3339Nano, f.TradeTime)
+	if err != nil {
+		return ExchangeFill{}, false
+	}
+
+	price, _ := strconv.ParseFloat(f.Price, 64)
+	size, _ := strconv.ParseFloat(f.Size, 64)
+	fee, _ := strconv.ParseFloat(f.Commission, 64)
+	if f.SizeInQuote && price > 0 {
+		size /= price
+	}
+
+	tradeID := f.EntryID
+	if tradeID == "" {
+		tradeID = f.TradeID
+	}
+	return ExchangeFill{
+		TradeID:    tradeID,
+		BaseAsset:  normalizeExchangeAsset(base),
+		QuoteAsset: normalizeExchangeAsset(quote),
+		Side:       strings.ToLower(f.Side),
+		Quantity:   size,
+		Price:      price,
+		Fee:        fee,
+		FeeAsset:   normalizeExchangeAsset(quote),
+		ExecutedAt: executedAt.UTC(),
+	}, true
+}
+
+// token signs the JWT for one request; it names the method and path it authorizes
+func (c *coinbaseClient) token(method, path string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"sub": c.keyName,
+		"iss": "cdp",
+		"nbf": now.Unix(),
+		"exp": now.Add(2 * time.Minute).Unix(),
+		"uri": fmt.Sprintf("%s %s%s", method, CoinbaseAPIHost, path),
+	})
+	token.Header["kid"] = c.keyName
+	token.Header["nonce"] = hex.EncodeToString(nonce)
+	return token.SignedString(c.privateKey)
+}
+
+func (c *coinbaseClient) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if err := coinbaseRateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	token, err := c.token("GET", path)
+	if err != nil {
+		return fmt.Errorf("failed to sign Coinbase request: %w", err)
+	}
+
+	endpoint := "https://" + CoinbaseAPIHost + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Coinbase API error: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
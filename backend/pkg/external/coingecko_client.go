@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
 )
 
 const (
@@ -16,6 +20,8 @@ const (
 
 type CoinGeckoClient struct {
 	httpClient *http.Client
+	baseURL    string
+	mu         sync.RWMutex
 	apiKey     string
 	rateLimiter *RateLimiter
 }
@@ -23,13 +29,28 @@ type CoinGeckoClient struct {
 func NewCoinGeckoClient(apiKey string) *CoinGeckoClient {
 	return &CoinGeckoClient{
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
 		},
+		baseURL:     CoinGeckoAPIBase,
 		apiKey:      apiKey,
 		rateLimiter: NewRateLimiter(RateLimitPerMin, time.Minute),
 	}
 }
 
+// SetAPIKey rotates the CoinGecko key used for subsequent requests
+func (c *CoinGeckoClient) SetAPIKey(apiKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = apiKey
+}
+
+func (c *CoinGeckoClient) getAPIKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.apiKey
+}
+
 type TokenPrice struct {
 	USD         float64 `json:"usd"`
 	USD24hChange float64 `json:"usd_24h_change"`
@@ -44,15 +65,15 @@ func (c *CoinGeckoClient) GetTokenPrices(ctx context.Context, tokenIDs []string)
 	}
 
 	ids := strings.Join(tokenIDs, ",")
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd&include_24hr_change=true", CoinGeckoAPIBase, ids)
+	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd&include_24hr_change=true", c.baseURL, ids)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.apiKey != "" {
-		req.Header.Set("x-cg-pro-api-key", c.apiKey)
+	if apiKey := c.getAPIKey(); apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", apiKey)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -62,7 +83,7 @@ func (c *CoinGeckoClient) GetTokenPrices(ctx context.Context, tokenIDs []string)
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CoinGecko API error: %d", resp.StatusCode)
+		return nil, coinGeckoError(resp)
 	}
 
 	var prices PriceResponse
@@ -73,21 +94,70 @@ func (c *CoinGeckoClient) GetTokenPrices(ctx context.Context, tokenIDs []string)
 	return prices, nil
 }
 
+// GetTokenPricesByContract fetches current prices for tokens on a chain by contract
+// address, keyed by lowercased address. Contracts CoinGecko doesn't list are left out.
+func (c *CoinGeckoClient) GetTokenPricesByContract(ctx context.Context, chainID int, addresses []string) (PriceResponse, error) {
+	platform, ok := AssetPlatforms[chainID]
+	if !ok {
+		return nil, fmt.Errorf("no CoinGecko asset platform for chain %d", chainID)
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	lowered := make([]string, len(addresses))
+	for i, address := range addresses {
+		lowered[i] = strings.ToLower(address)
+	}
+	url := fmt.Sprintf("%s/simple/token_price/%s?contract_addresses=%s&vs_currencies=usd&include_24hr_change=true",
+		c.baseURL, platform, strings.Join(lowered, ","))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey := c.getAPIKey(); apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, coinGeckoError(resp)
+	}
+
+	var prices PriceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&prices); err != nil {
+		return nil, err
+	}
+
+	byAddress := make(PriceResponse, len(prices))
+	for address, price := range prices {
+		byAddress[strings.ToLower(address)] = price
+	}
+	return byAddress, nil
+}
+
 // GetPriceHistory fetches historical price data
 func (c *CoinGeckoClient) GetPriceHistory(ctx context.Context, tokenID string, days int) ([][]float64, error) {
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/coins/%s/market_chart?vs_currency=usd&days=%d", CoinGeckoAPIBase, tokenID, days)
+	url := fmt.Sprintf("%s/coins/%s/market_chart?vs_currency=usd&days=%d", c.baseURL, tokenID, days)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.apiKey != "" {
-		req.Header.Set("x-cg-pro-api-key", c.apiKey)
+	if apiKey := c.getAPIKey(); apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", apiKey)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -97,7 +167,7 @@ func (c *CoinGeckoClient) GetPriceHistory(ctx context.Context, tokenID string, d
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CoinGecko API error: %d", resp.StatusCode)
+		return nil, coinGeckoError(resp)
 	}
 
 	var data struct {
@@ -123,4 +193,135 @@ var TokenIDMappings = map[string]string{
 	"link":  "chainlink",
 	"matic": "matic-network",
 	"pol":   "matic-network", // POL is the new Polygon token symbol
-}
\ No newline at end of file
+	// Liquid staking tokens
+	"steth":  "staked-ether",
+	"wsteth": "wrapped-steth",
+	"reth":   "rocket-pool-eth",
+	"cbeth":  "coinbase-wrapped-staked-eth",
+	// Cosmos SDK chains
+	"atom": "cosmos",
+	"osmo": "osmosis",
+	"tia":  "celestia",
+	// Bitcoin
+	"btc": "bitcoin",
+}
+
+// GetHistoricalPrice fetches the USD price of a token on the day of the given time.
+// CoinGecko only exposes daily granularity for this endpoint.
+func (c *CoinGeckoClient) GetHistoricalPrice(ctx context.Context, tokenID string, at time.Time) (float64, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+
+	url := fmt.Sprintf("%s/coins/%s/history?date=%s&localization=false", c.baseURL, tokenID, at.UTC().Format("02-01-2006"))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if apiKey := c.getAPIKey(); apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, coinGeckoError(resp)
+	}
+
+	var data struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return 0, err
+	}
+
+	price, ok := data.MarketData.CurrentPrice["usd"]
+	if !ok {
+		return 0, fmt.Errorf("no USD price for %s on %s", tokenID, at.UTC().Format("2006-01-02"))
+	}
+
+	return price, nil
+}
+
+// TrendingCoin is one of the coins most searched on CoinGecko
+type TrendingCoin struct {
+	ID            string
+	Symbol        string
+	Name          string
+	MarketCapRank int
+	Thumb         string
+	PriceUSD      float64
+	// PriceChange24h is in percent
+	PriceChange24h float64
+}
+
+// GetTrendingCoins fetches the coins most searched on CoinGecko over the last day, most
+// searched first
+func (c *CoinGeckoClient) GetTrendingCoins(ctx context.Context) ([]TrendingCoin, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/search/trending", c.baseURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey := c.getAPIKey(); apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, coinGeckoError(resp)
+	}
+
+	var data struct {
+		Coins []struct {
+			Item struct {
+				ID            string `json:"id"`
+				Symbol        string `json:"symbol"`
+				Name          string `json:"name"`
+				MarketCapRank int    `json:"market_cap_rank"`
+				Thumb         string `json:"thumb"`
+				Data          struct {
+					Price                    float64            `json:"price"`
+					PriceChangePercentage24h map[string]float64 `json:"price_change_percentage_24h"`
+				} `json:"data"`
+			} `json:"item"`
+		} `json:"coins"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	coins := make([]TrendingCoin, 0, len(data.Coins))
+	for _, entry := range data.Coins {
+		item := entry.Item
+		coins = append(coins, TrendingCoin{
+			ID:             item.ID,
+			Symbol:         item.Symbol,
+			Name:           item.Name,
+			MarketCapRank:  item.MarketCapRank,
+			Thumb:          item.Thumb,
+			PriceUSD:       item.Data.Price,
+			PriceChange24h: item.Data.PriceChangePercentage24h["usd"],
+		})
+	}
+	return coins, nil
+}
@@ -0,0 +1,127 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCoinGeckoClient(t *testing.T, cassette string) *CoinGeckoClient {
+	server := vcr.Replay(t, cassette)
+	client := NewCoinGeckoClient("test-key")
+	client.baseURL = server.URL
+	t.Cleanup(client.rateLimiter.Stop)
+	return client
+}
+
+func TestCoinGeckoGetTokenPrices(t *testing.T) {
+	client := newTestCoinGeckoClient(t, "testdata/coingecko/prices.json")
+	ctx := context.Background()
+
+	prices, err := client.GetTokenPrices(ctx, []string{"ethereum", "usd-coin", "delisted-token"})
+	require.NoError(t, err)
+	assert.Len(t, prices, 2, "coins without a price are left out")
+	assert.Equal(t, 3412.57, prices["ethereum"].USD)
+	assert.InDelta(t, -1.8423456789, prices["ethereum"].USD24hChange, 1e-9)
+	assert.Equal(t, 0.999874, prices["usd-coin"].USD)
+
+	_, err = client.GetTokenPrices(ctx, []string{"ethereum"})
+	assert.EqualError(t, err, "CoinGecko API error: 429")
+	quotaErr, ok := AsQuotaError(err)
+	require.True(t, ok, "a 429 is an exhausted quota")
+	assert.Equal(t, ProviderCoinGecko, quotaErr.Provider)
+	assert.Equal(t, 30*time.Second, quotaErr.ResetAfter())
+}
+
+func TestCoinGeckoGetTokenPricesByContract(t *testing.T) {
+	client := newTestCoinGeckoClient(t, "testdata/coingecko/token_prices.json")
+	ctx := context.Background()
+
+	prices, err := client.GetTokenPricesByContract(ctx, 1, []string{
+		"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		"0x1111111111111111111111111111111111111111",
+	})
+	require.NoError(t, err)
+	assert.Len(t, prices, 1, "contracts without a price are left out")
+	assert.Equal(t, 0.999874, prices["0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"].USD)
+
+	_, err = client.GetTokenPricesByContract(ctx, 56, []string{"0x55d398326f99059fF775485246999027B3197955"})
+	assert.EqualError(t, err, "no CoinGecko asset platform for chain 56")
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	assert.Zero(t, RetryAfter(header, now))
+
+	header.Set("Retry-After", "120")
+	assert.Equal(t, 2*time.Minute, RetryAfter(header, now))
+	header.Set("Retry-After", "Fri, 15 Mar 2024 12:00:45 GMT")
+	assert.Equal(t, 45*time.Second, RetryAfter(header, now))
+	header.Set("Retry-After", "Fri, 15 Mar 2024 11:59:00 GMT")
+	assert.Zero(t, RetryAfter(header, now), "already past")
+
+	assert.Equal(t, time.Minute, (&QuotaError{Err: errors.New("throttled")}).ResetAfter(), "the default without a header")
+}
+
+func TestCoinGeckoGetHistoricalPrice(t *testing.T) {
+	client := newTestCoinGeckoClient(t, "testdata/coingecko/history.json")
+	ctx := context.Background()
+	// Late in the UTC day, so the date must not drift with the local zone
+	at := time.Date(2024, 3, 15, 23, 30, 0, 0, time.UTC)
+
+	price, err := client.GetHistoricalPrice(ctx, "ethereum", at)
+	require.NoError(t, err)
+	assert.Equal(t, 3690.12, price)
+
+	// Coins listed after the date come back without market data
+	_, err = client.GetHistoricalPrice(ctx, "fresh-token", at)
+	assert.EqualError(t, err, "no USD price for fresh-token on 2024-03-15")
+}
+
+func TestCoinGeckoGetCoinInfo(t *testing.T) {
+	client := newTestCoinGeckoClient(t, "testdata/coingecko/coin_info.json")
+	ctx := context.Background()
+
+	info, err := client.GetCoinInfoByContract(ctx, 137, "0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	require.NoError(t, err)
+	assert.Equal(t, "usd-coin", info.ID)
+	assert.Equal(t, []string{"Stablecoins", "USD Stablecoin"}, info.Categories)
+	assert.Equal(t, "https://www.circle.com/en/usdc", info.Website())
+	assert.Equal(t, "https://discord.com/invite/buildoncircle", info.Discord())
+	assert.Equal(t, "https://github.com/centrehq/centre-tokens", info.GitHub())
+	require.NotNil(t, info.MarketData)
+	require.NotNil(t, info.MarketData.CirculatingSupply)
+	assert.Equal(t, 34129876543.21, *info.MarketData.CirculatingSupply)
+
+	_, err = client.GetCoinInfo(ctx, "not-a-coin")
+	assert.True(t, errors.Is(err, ErrCoinNotFound))
+
+	_, err = client.GetCoinInfoByContract(ctx, 56, "0x55d398326f99059fF775485246999027B3197955")
+	assert.EqualError(t, err, "no CoinGecko asset platform for chain 56")
+}
+
+func TestCoinGeckoGetTrendingCoins(t *testing.T) {
+	client := newTestCoinGeckoClient(t, "testdata/coingecko/trending.json")
+
+	coins, err := client.GetTrendingCoins(context.Background())
+	require.NoError(t, err)
+	require.Len(t, coins, 2)
+	assert.Equal(t, TrendingCoin{
+		ID:             "pendle",
+		Symbol:         "PENDLE",
+		Name:           "Pendle",
+		MarketCapRank:  61,
+		Thumb:          "https://coin-images.coingecko.com/coins/images/15069/thumb/Pendle_Logo_Normal-03.png",
+		PriceUSD:       4.61,
+		PriceChange24h: 12.84,
+	}, coins[0])
+	assert.Zero(t, coins[1].MarketCapRank, "unranked coins have no market cap rank")
+	assert.Equal(t, -23.5, coins[1].PriceChange24h)
+}
@@ -0,0 +1,127 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrCoinNotFound is returned when CoinGecko doesn't list a coin or contract
+var ErrCoinNotFound = errors.New("coin not found on CoinGecko")
+
+// AssetPlatforms maps chain IDs to CoinGecko asset platform IDs for contract lookups
+var AssetPlatforms = map[int]string{
+	1:     "ethereum",
+	137:   "polygon-pos",
+	42161: "arbitrum-one",
+	10:    "optimistic-ethereum",
+}
+
+// CoinInfo is the descriptive subset of CoinGecko's coin data
+type CoinInfo struct {
+	ID          string   `json:"id"`
+	Symbol      string   `json:"symbol"`
+	Name        string   `json:"name"`
+	Categories  []string `json:"categories"`
+	Description struct {
+		EN string `json:"en"`
+	} `json:"description"`
+	Links struct {
+		Homepage          []string `json:"homepage"`
+		TwitterScreenName string   `json:"twitter_screen_name"`
+		TelegramChannelID string   `json:"telegram_channel_identifier"`
+		ChatURL           []string `json:"chat_url"`
+		ReposURL          struct {
+			GitHub []string `json:"github"`
+		} `json:"repos_url"`
+	} `json:"links"`
+	Image struct {
+		Large string `json:"large"`
+	} `json:"image"`
+	MarketData *struct {
+		CirculatingSupply *float64 `json:"circulating_supply"`
+	} `json:"market_data"`
+}
+
+// Website returns the first non-empty homepage link
+func (i *CoinInfo) Website() string {
+	return firstNonEmpty(i.Links.Homepage)
+}
+
+// Discord returns the first chat link pointing at Discord
+func (i *CoinInfo) Discord() string {
+	for _, url := range i.Links.ChatURL {
+		if strings.Contains(url, "discord") {
+			return url
+		}
+	}
+	return ""
+}
+
+// GitHub returns the first GitHub repository link
+func (i *CoinInfo) GitHub() string {
+	return firstNonEmpty(i.Links.ReposURL.GitHub)
+}
+
+func firstNonEmpty(values []string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// GetCoinInfo fetches descriptive data for a CoinGecko coin ID
+func (c *CoinGeckoClient) GetCoinInfo(ctx context.Context, coinID string) (*CoinInfo, error) {
+	return c.getCoinInfo(ctx, fmt.Sprintf("%s/coins/%s", c.baseURL, coinID))
+}
+
+// GetCoinInfoByContract fetches descriptive data for a token contract on a supported chain
+func (c *CoinGeckoClient) GetCoinInfoByContract(ctx context.Context, chainID int, address string) (*CoinInfo, error) {
+	platform, ok := AssetPlatforms[chainID]
+	if !ok {
+		return nil, fmt.Errorf("no CoinGecko asset platform for chain %d", chainID)
+	}
+	return c.getCoinInfo(ctx, fmt.Sprintf("%s/coins/%s/contract/%s", c.baseURL, platform, strings.ToLower(address)))
+}
+
+func (c *CoinGeckoClient) getCoinInfo(ctx context.Context, baseURL string) (*CoinInfo, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	url := baseURL + "?localization=false&tickers=false&market_data=true&community_data=false&developer_data=false&sparkline=false"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey := c.getAPIKey(); apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrCoinNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, coinGeckoError(resp)
+	}
+
+	var info CoinInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,180 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	CosmosRateLimit = 120 // Public LCD endpoints throttle per IP
+	// cosmosPageSize is the page size requested from paginated LCD queries
+	cosmosPageSize = 200
+)
+
+// CosmosClient queries a Cosmos SDK chain through its LCD (REST) endpoint
+type CosmosClient struct {
+	httpClient  *http.Client
+	baseURL     string
+	rateLimiter *RateLimiter
+}
+
+func NewCosmosClient(baseURL string) *CosmosClient {
+	return &CosmosClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		baseURL:     baseURL,
+		rateLimiter: NewRateLimiter(CosmosRateLimit, time.Minute),
+	}
+}
+
+// CosmosCoin is an integer amount of a denom in its smallest unit
+type CosmosCoin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// CosmosDelegation is a delegator's stake with one validator
+type CosmosDelegation struct {
+	Delegation struct {
+		DelegatorAddress string `json:"delegator_address"`
+		ValidatorAddress string `json:"validator_address"`
+		Shares           string `json:"shares"`
+	} `json:"delegation"`
+	Balance CosmosCoin `json:"balance"`
+}
+
+// CosmosValidatorRewards are a delegator's unclaimed rewards from one validator. Reward
+// amounts are decimals with 18 fractional digits, e.g. "1234.567000000000000000".
+type CosmosValidatorRewards struct {
+	ValidatorAddress string       `json:"validator_address"`
+	Reward           []CosmosCoin `json:"reward"`
+}
+
+// CosmosDenomTrace is the origin of an IBC voucher denom
+type CosmosDenomTrace struct {
+	Path      string `json:"path"`
+	BaseDenom string `json:"base_denom"`
+}
+
+type cosmosPagination struct {
+	NextKey *string `json:"next_key"`
+}
+
+// GetBalances fetches every bank balance of an address
+func (c *CosmosClient) GetBalances(ctx context.Context, address string) ([]CosmosCoin, error) {
+	var all []CosmosCoin
+	err := c.paginate(ctx, "/cosmos/bank/v1beta1/balances/"+address, func(data []byte) (*cosmosPagination, error) {
+		var page struct {
+			Balances   []CosmosCoin     `json:"balances"`
+			Pagination cosmosPagination `json:"pagination"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Balances...)
+		return &page.Pagination, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// GetDelegations fetches an address's delegations across all validators
+func (c *CosmosClient) GetDelegations(ctx context.Context, address string) ([]CosmosDelegation, error) {
+	var all []CosmosDelegation
+	err := c.paginate(ctx, "/cosmos/staking/v1beta1/delegations/"+address, func(data []byte) (*cosmosPagination, error) {
+		var page struct {
+			DelegationResponses []CosmosDelegation `json:"delegation_responses"`
+			Pagination          cosmosPagination   `json:"pagination"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.DelegationResponses...)
+		return &page.Pagination, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// GetRewards fetches an address's unclaimed staking rewards per validator
+func (c *CosmosClient) GetRewards(ctx context.Context, address string) ([]CosmosValidatorRewards, error) {
+	var response struct {
+		Rewards []CosmosValidatorRewards `json:"rewards"`
+	}
+	if err := c.get(ctx, "/cosmos/distribution/v1beta1/delegators/"+address+"/rewards", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Rewards, nil
+}
+
+// GetDenomTrace resolves the hash of an ibc/<hash> denom to its transfer path and base denom
+func (c *CosmosClient) GetDenomTrace(ctx context.Context, hash string) (*CosmosDenomTrace, error) {
+	var response struct {
+		DenomTrace CosmosDenomTrace `json:"denom_trace"`
+	}
+	if err := c.get(ctx, "/ibc/apps/transfer/v1/denom_traces/"+hash, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.DenomTrace, nil
+}
+
+// paginate follows next_key through a paginated query, handing each raw page to decode
+func (c *CosmosClient) paginate(ctx context.Context, path string, decode func([]byte) (*cosmosPagination, error)) error {
+	query := url.Values{}
+	query.Set("pagination.limit", fmt.Sprint(cosmosPageSize))
+	for {
+		var raw json.RawMessage
+		if err := c.get(ctx, path, query, &raw); err != nil {
+			return err
+		}
+		pagination, err := decode(raw)
+		if err != nil {
+			return err
+		}
+		if pagination.NextKey == nil || *pagination.NextKey == "" {
+			return nil
+		}
+		query.Set("pagination.key", *pagination.NextKey)
+	}
+}
+
+func (c *CosmosClient) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Cosmos LCD error: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
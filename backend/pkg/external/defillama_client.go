@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
 )
 
 const (
@@ -15,14 +18,17 @@ const (
 
 type DefiLlamaClient struct {
 	httpClient  *http.Client
+	baseURL     string
 	rateLimiter *RateLimiter
 }
 
 func NewDefiLlamaClient() *DefiLlamaClient {
 	return &DefiLlamaClient{
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
 		},
+		baseURL:     DefiLlamaAPIBase,
 		rateLimiter: NewRateLimiter(DefiLlamaRateLimit, time.Minute),
 	}
 }
@@ -36,6 +42,12 @@ type YieldPool struct {
 	APY         float64 `json:"apy"`
 	APYBase     float64 `json:"apyBase"`
 	APYReward   float64 `json:"apyReward"`
+	// RewardTokens are the addresses of the tokens paying APYReward
+	RewardTokens []string `json:"rewardTokens"`
+	// APYPct1D and APYPct7D are the changes in APY over the last day and week, in
+	// percentage points
+	APYPct1D    float64 `json:"apyPct1D"`
+	APYPct7D    float64 `json:"apyPct7D"`
 	IL7d        float64 `json:"il7d"`
 	Exposure    string  `json:"exposure"`
 	StableCoin  bool    `json:"stablecoin"`
@@ -47,7 +59,7 @@ func (c *DefiLlamaClient) GetYieldPools(ctx context.Context) ([]YieldPool, error
 		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/pools", DefiLlamaAPIBase)
+	url := fmt.Sprintf("%s/pools", c.baseURL)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -103,7 +115,7 @@ func (c *DefiLlamaClient) GetProtocolTVL(ctx context.Context, protocol string) (
 		return nil, err
 	}
 
-	url := fmt.Sprintf("%s/protocol/%s", DefiLlamaAPIBase, protocol)
+	url := fmt.Sprintf("%s/protocol/%s", c.baseURL, protocol)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -140,6 +152,49 @@ func (c *DefiLlamaClient) GetProtocolTVL(ctx context.Context, protocol string) (
 	}, nil
 }
 
+// LlamaProtocol is a protocol entry from DefiLlama's /protocols listing
+type LlamaProtocol struct {
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug"`
+	Category string  `json:"category"`
+	TVL      float64 `json:"tvl"`
+	// ChainTVLs also carries DefiLlama's extra buckets ("staking", "Ethereum-borrowed", ...)
+	ChainTVLs map[string]float64 `json:"chainTvls"`
+}
+
+// GetProtocols fetches current total and per-chain TVL for every protocol in one call
+func (c *DefiLlamaClient) GetProtocols(ctx context.Context) ([]LlamaProtocol, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/protocols", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The full listing is several megabytes, so allow more than the default timeout
+	client := *c.httpClient
+	client.Timeout = 30 * time.Second
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DefiLlama API error: %d", resp.StatusCode)
+	}
+
+	var protocols []LlamaProtocol
+	if err := json.NewDecoder(resp.Body).Decode(&protocols); err != nil {
+		return nil, err
+	}
+
+	return protocols, nil
+}
+
 // Chain name mappings
 var ChainMappings = map[string]string{
 	"ethereum": "Ethereum",
@@ -0,0 +1,64 @@
+package external
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDefiLlamaClient(t *testing.T, cassette string) *DefiLlamaClient {
+	server := vcr.Replay(t, cassette)
+	client := NewDefiLlamaClient()
+	client.baseURL = server.URL
+	t.Cleanup(client.rateLimiter.Stop)
+	return client
+}
+
+func TestDefiLlamaGetYieldPools(t *testing.T) {
+	client := newTestDefiLlamaClient(t, "testdata/defillama/pools.json")
+	ctx := context.Background()
+
+	pools, err := client.GetYieldPools(ctx)
+	require.NoError(t, err)
+	require.Len(t, pools, 2)
+
+	// Nulls for missing reward APY and IL leave the zero value
+	assert.Equal(t, YieldPool{
+		Pool:     "747c1d2a-c668-4682-b9f9-296708a3dd90",
+		Project:  "lido",
+		Symbol:   "STETH",
+		Chain:    "Ethereum",
+		TVL:      24873014533,
+		APY:      2.987,
+		APYBase:  2.987,
+		Exposure: "single",
+	}, pools[0])
+	assert.Equal(t, 1.5, pools[1].APYReward)
+	assert.Equal(t, -0.83, pools[1].IL7d)
+	assert.Equal(t, 2.31, pools[1].APYPct1D)
+	assert.Equal(t, -4.05, pools[1].APYPct7D)
+	assert.Equal(t, "multi", pools[1].Exposure)
+
+	_, err = client.GetYieldPools(ctx)
+	assert.EqualError(t, err, "DefiLlama API error: 502")
+}
+
+func TestDefiLlamaGetProtocols(t *testing.T) {
+	client := newTestDefiLlamaClient(t, "testdata/defillama/protocols.json")
+
+	protocols, err := client.GetProtocols(context.Background())
+	require.NoError(t, err)
+	require.Len(t, protocols, 2)
+
+	assert.Equal(t, "lido", protocols[0].Slug)
+	assert.Equal(t, "Liquid Staking", protocols[0].Category)
+	assert.Equal(t, 25012345678.9, protocols[0].TVL)
+	assert.Len(t, protocols[0].ChainTVLs, 3)
+
+	// Extra buckets come through alongside the chains
+	assert.Equal(t, float64(9000000000), protocols[1].ChainTVLs["Ethereum-borrowed"])
+	assert.Equal(t, float64(15000000000), protocols[1].ChainTVLs["Ethereum"])
+}
@@ -0,0 +1,135 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	EsploraRateLimit = 300 // Blockstream's public API throttles bursts per IP
+	// EsploraPageSize is how many confirmed transactions an address listing returns per page
+	EsploraPageSize = 25
+)
+
+// EsploraClient reads Bitcoin data from an Esplora HTTP API (Blockstream, mempool.space,
+// or electrs with its HTTP interface)
+type EsploraClient struct {
+	httpClient  *http.Client
+	baseURL     string
+	rateLimiter *RateLimiter
+}
+
+func NewEsploraClient(baseURL string) *EsploraClient {
+	return &EsploraClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		rateLimiter: NewRateLimiter(EsploraRateLimit, time.Minute),
+	}
+}
+
+// EsploraAddressStats are an address's output totals in satoshis
+type EsploraAddressStats struct {
+	FundedTxoCount int   `json:"funded_txo_count"`
+	FundedTxoSum   int64 `json:"funded_txo_sum"`
+	SpentTxoCount  int   `json:"spent_txo_count"`
+	SpentTxoSum    int64 `json:"spent_txo_sum"`
+	TxCount        int   `json:"tx_count"`
+}
+
+// EsploraAddress is an address's confirmed and unconfirmed activity
+type EsploraAddress struct {
+	Address      string              `json:"address"`
+	ChainStats   EsploraAddressStats `json:"chain_stats"`
+	MempoolStats EsploraAddressStats `json:"mempool_stats"`
+}
+
+// BalanceSats returns the address balance including unconfirmed transactions
+func (a *EsploraAddress) BalanceSats() int64 {
+	return a.ChainStats.FundedTxoSum - a.ChainStats.SpentTxoSum +
+		a.MempoolStats.FundedTxoSum - a.MempoolStats.SpentTxoSum
+}
+
+// Used reports whether the address has ever appeared in a transaction
+func (a *EsploraAddress) Used() bool {
+	return a.ChainStats.TxCount > 0 || a.MempoolStats.TxCount > 0
+}
+
+// EsploraOutput is a transaction output; Address is empty for non-standard scripts
+type EsploraOutput struct {
+	Address string `json:"scriptpubkey_address"`
+	Value   int64  `json:"value"`
+}
+
+// EsploraTransaction is a transaction with its inputs' previous outputs resolved
+type EsploraTransaction struct {
+	Txid   string `json:"txid"`
+	Fee    int64  `json:"fee"`
+	Status struct {
+		Confirmed   bool  `json:"confirmed"`
+		BlockHeight int64 `json:"block_height"`
+		BlockTime   int64 `json:"block_time"` // Unix seconds
+	} `json:"status"`
+	Vin []struct {
+		Prevout    *EsploraOutput `json:"prevout"` // nil for coinbase inputs
+		IsCoinbase bool           `json:"is_coinbase"`
+	} `json:"vin"`
+	Vout []EsploraOutput `json:"vout"`
+}
+
+// GetAddress fetches an address's balance and transaction counts
+func (c *EsploraClient) GetAddress(ctx context.Context, address string) (*EsploraAddress, error) {
+	var result EsploraAddress
+	if err := c.get(ctx, "/address/"+address, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAddressTransactions fetches a page of an address's confirmed transactions, newest
+// first. Pass the last txid of the previous page to continue; a page shorter than
+// EsploraPageSize is the last one.
+func (c *EsploraClient) GetAddressTransactions(ctx context.Context, address, lastSeenTxid string) ([]EsploraTransaction, error) {
+	path := "/address/" + address + "/txs/chain"
+	if lastSeenTxid != "" {
+		path += "/" + lastSeenTxid
+	}
+
+	var txs []EsploraTransaction
+	if err := c.get(ctx, path, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (c *EsploraClient) get(ctx context.Context, path string, out interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Esplora API error: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,200 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	// EtherscanAPIBase is Etherscan's multichain API, which takes the chain as chainid
+	EtherscanAPIBase = "https://api.etherscan.io/v2/api"
+	// EtherscanRateLimit stays under the free tier's 5 calls per second
+	EtherscanRateLimit = 240
+)
+
+// ErrEtherscanNoData is returned when Etherscan has no data for a token on the chain
+var ErrEtherscanNoData = errors.New("no data found on Etherscan")
+
+// EtherscanClient reads token and contract data from Etherscan's explorer API
+type EtherscanClient struct {
+	httpClient  *http.Client
+	baseURL     string
+	apiKey      string
+	rateLimiter *RateLimiter
+}
+
+func NewEtherscanClient(apiKey string) *EtherscanClient {
+	return &EtherscanClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		baseURL:     EtherscanAPIBase,
+		apiKey:      apiKey,
+		rateLimiter: NewRateLimiter(EtherscanRateLimit, time.Minute),
+	}
+}
+
+// TokenHolder is an address's balance of a token in its smallest unit
+type TokenHolder struct {
+	Address string `json:"TokenHolderAddress"`
+	Balance string `json:"TokenHolderQuantity"`
+}
+
+// GetTopHolders fetches a token's largest holders, largest first
+func (c *EtherscanClient) GetTopHolders(ctx context.Context, chainID int, contract string, limit int) ([]TokenHolder, error) {
+	var holders []TokenHolder
+	err := c.get(ctx, chainID, url.Values{
+		"module":          {"token"},
+		"action":          {"topholders"},
+		"contractaddress": {strings.ToLower(contract)},
+		"offset":          {strconv.Itoa(limit)},
+	}, &holders)
+	if err != nil {
+		return nil, err
+	}
+	return holders, nil
+}
+
+// GetTokenSupply fetches a token's total supply in its smallest unit
+func (c *EtherscanClient) GetTokenSupply(ctx context.Context, chainID int, contract string) (string, error) {
+	var supply string
+	err := c.get(ctx, chainID, url.Values{
+		"module":          {"stats"},
+		"action":          {"tokensupply"},
+		"contractaddress": {strings.ToLower(contract)},
+	}, &supply)
+	if err != nil {
+		return "", err
+	}
+	return supply, nil
+}
+
+// etherscanResponse wraps every Etherscan result; failures have status "0" and the
+// reason in message or, as a string, in result
+type etherscanResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+func (c *EtherscanClient) get(ctx context.Context, chainID int, params url.Values, out interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	params.Set("chainid", strconv.Itoa(chainID))
+	params.Set("apikey", c.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Etherscan API error: %d", resp.StatusCode)
+	}
+
+	var result etherscanResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Status != "1" {
+		if strings.HasPrefix(result.Message, "No data found") {
+			return ErrEtherscanNoData
+		}
+		var reason string
+		if json.Unmarshal(result.Result, &reason) != nil || reason == "" {
+			reason = result.Message
+		}
+		return fmt.Errorf("Etherscan API error: %s", reason)
+	}
+	return json.Unmarshal(result.Result, out)
+}
+
+// ContractSource is what Etherscan knows of a contract's source. Contracts whose source
+// wasn't verified, and addresses that aren't contracts, have an empty SourceCode.
+type ContractSource struct {
+	SourceCode     string `json:"SourceCode"`
+	ContractName   string `json:"ContractName"`
+	Proxy          string `json:"Proxy"`
+	Implementation string `json:"Implementation"`
+}
+
+// Verified reports whether the contract's source was verified
+func (s *ContractSource) Verified() bool {
+	return s.SourceCode != ""
+}
+
+// IsProxy reports whether Etherscan detected the contract as a proxy
+func (s *ContractSource) IsProxy() bool {
+	return s.Proxy == "1"
+}
+
+// GetSourceCode fetches a contract's verified source and proxy detection
+func (c *EtherscanClient) GetSourceCode(ctx context.Context, chainID int, address string) (*ContractSource, error) {
+	var sources []ContractSource
+	err := c.get(ctx, chainID, url.Values{
+		"module":  {"contract"},
+		"action":  {"getsourcecode"},
+		"address": {strings.ToLower(address)},
+	}, &sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, ErrEtherscanNoData
+	}
+	return &sources[0], nil
+}
+
+// ContractCreation is the deployment of a contract
+type ContractCreation struct {
+	ContractAddress string `json:"contractAddress"`
+	Creator         string `json:"contractCreator"`
+	TxHash          string `json:"txHash"`
+	BlockNumber     string `json:"blockNumber"`
+	Timestamp       string `json:"timestamp"` // Unix seconds
+}
+
+// DeployedAt returns when the contract was deployed, or false when Etherscan didn't say
+func (c *ContractCreation) DeployedAt() (time.Time, bool) {
+	seconds, err := strconv.ParseInt(c.Timestamp, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
+
+// GetContractCreation fetches a contract's deployment. Addresses that aren't contracts
+// give ErrEtherscanNoData.
+func (c *EtherscanClient) GetContractCreation(ctx context.Context, chainID int, address string) (*ContractCreation, error) {
+	var creations []ContractCreation
+	err := c.get(ctx, chainID, url.Values{
+		"module":            {"contract"},
+		"action":            {"getcontractcreation"},
+		"contractaddresses": {strings.ToLower(address)},
+	}, &creations)
+	if err != nil {
+		return nil, err
+	}
+	if len(creations) == 0 {
+		return nil, ErrEtherscanNoData
+	}
+	return &creations[0], nil
+}
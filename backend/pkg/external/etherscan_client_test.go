@@ -0,0 +1,69 @@
+package external
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtherscanTokenHolders(t *testing.T) {
+	server := vcr.Replay(t, "testdata/etherscan/holders.json")
+	client := NewEtherscanClient("test-key")
+	client.baseURL = server.URL + "/"
+	t.Cleanup(client.rateLimiter.Stop)
+	ctx := context.Background()
+	pepe := "0x6982508145454Ce325dDbE47a25d4ec3d2311933"
+
+	holders, err := client.GetTopHolders(ctx, 1, pepe, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []TokenHolder{
+		{Address: "0xf977814e90da44bfa03b6295a0616a897441acec", Balance: "120000000000000000000000000000000"},
+		{Address: "0x5a52e96bacdabb82fd05763e25335261b270efcb", Balance: "42000000000000000000000000000000"},
+	}, holders)
+
+	supply, err := client.GetTokenSupply(ctx, 1, pepe)
+	require.NoError(t, err)
+	assert.Equal(t, "420690000000000000000000000000000", supply)
+
+	_, err = client.GetTopHolders(ctx, 10, pepe, 2)
+	assert.ErrorIs(t, err, ErrEtherscanNoData)
+
+	_, err = client.GetTokenSupply(ctx, 10, pepe)
+	assert.EqualError(t, err, "Etherscan API error: Invalid API Key")
+}
+
+func TestEtherscanContracts(t *testing.T) {
+	server := vcr.Replay(t, "testdata/etherscan/contracts.json")
+	client := NewEtherscanClient("test-key")
+	client.baseURL = server.URL + "/"
+	t.Cleanup(client.rateLimiter.Stop)
+	ctx := context.Background()
+	usdc := "0xA0b86991c6218b36c1D19D4a2e9Eb0cE3606eB48"
+	eoa := "0x00000000000000000000000000000000DeadBeef"
+
+	source, err := client.GetSourceCode(ctx, 1, usdc)
+	require.NoError(t, err)
+	assert.True(t, source.Verified())
+	assert.True(t, source.IsProxy())
+	assert.Equal(t, "FiatTokenProxy", source.ContractName)
+	assert.Equal(t, "0x43506849d7c04f9138d1a2050bbf3a0c054402dd", source.Implementation)
+
+	source, err = client.GetSourceCode(ctx, 1, eoa)
+	require.NoError(t, err)
+	assert.False(t, source.Verified())
+	assert.False(t, source.IsProxy())
+
+	creation, err := client.GetContractCreation(ctx, 1, usdc)
+	require.NoError(t, err)
+	assert.Equal(t, "0x95ba4cf87d6723ad9c0db21737d862be80e93911", creation.Creator)
+	deployedAt, ok := creation.DeployedAt()
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2018, 8, 3, 19, 28, 24, 0, time.UTC), deployedAt)
+
+	_, err = client.GetContractCreation(ctx, 1, eoa)
+	assert.ErrorIs(t, err, ErrEtherscanNoData, "addresses that aren't contracts have no creation")
+}
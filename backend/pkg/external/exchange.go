@@ -0,0 +1,105 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported centralized exchanges
+const (
+	ExchangeBinance  = "binance"
+	ExchangeCoinbase = "coinbase"
+	ExchangeKraken   = "kraken"
+)
+
+// ExchangeCredentials are a user's read-only exchange API key. For Coinbase the key is the
+// CDP key name and the secret its PEM encoded EC private key.
+type ExchangeCredentials struct {
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+}
+
+// ExchangeBalance is an exchange account's holding of one asset
+type ExchangeBalance struct {
+	Asset  string
+	Free   float64
+	Locked float64
+}
+
+// ExchangeFill is one executed spot trade. Quantity is in the base asset and Price in
+// the quote asset; Side is "buy" or "sell" of the base asset.
+type ExchangeFill struct {
+	TradeID    string
+	BaseAsset  string
+	QuoteAsset string
+	Side       string
+	Quantity   float64
+	Price      float64
+	Fee        float64
+	FeeAsset   string
+	ExecutedAt time.Time
+}
+
+// ExchangeConnector reads an exchange account with a read-only API key
+type ExchangeConnector interface {
+	// GetBalances returns the account's non-zero spot balances
+	GetBalances(ctx context.Context) ([]ExchangeBalance, error)
+	// GetTrades returns spot fills executed at or after since, oldest first. A zero since
+	// reads the full history the exchange exposes.
+	GetTrades(ctx context.Context, since time.Time) ([]ExchangeFill, error)
+}
+
+// NewExchangeConnector creates the connector for an exchange
+func NewExchangeConnector(exchange string, creds ExchangeCredentials) (ExchangeConnector, error) {
+	if creds.APIKey == "" || creds.APISecret == "" {
+		return nil, fmt.Errorf("api key and secret are required")
+	}
+
+	switch exchange {
+	case ExchangeBinance:
+		return newBinanceClient(creds), nil
+	case ExchangeCoinbase:
+		return newCoinbaseClient(creds)
+	case ExchangeKraken:
+		return newKrakenClient(creds)
+	default:
+		return nil, fmt.Errorf("unsupported exchange: %s", exchange)
+	}
+}
+
+// IsSupportedExchange reports whether a connector exists for the exchange
+func IsSupportedExchange(exchange string) bool {
+	switch exchange {
+	case ExchangeBinance, ExchangeCoinbase, ExchangeKraken:
+		return true
+	default:
+		return false
+	}
+}
+
+// usdAssets are priced at one dollar: US dollar balances and the major USD stablecoins
+var usdAssets = map[string]bool{
+	"USD": true, "USDT": true, "USDC": true, "BUSD": true, "FDUSD": true,
+	"DAI": true, "TUSD": true, "USDP": true, "PYUSD": true,
+}
+
+// IsUSDAsset reports whether an exchange asset is valued at one dollar
+func IsUSDAsset(asset string) bool {
+	return usdAssets[asset]
+}
+
+// normalizeExchangeAsset maps exchange specific tickers to the common symbol
+func normalizeExchangeAsset(asset string) string {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	switch asset {
+	case "XBT":
+		return "BTC"
+	case "XDG":
+		return "DOGE"
+	case "ETH2":
+		return "ETH"
+	}
+	return asset
+}
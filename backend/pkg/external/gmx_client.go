@@ -0,0 +1,152 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	GMXArbitrumAPIBase = "https://arbitrum-api.gmxinfra.io"
+	GMXRateLimit       = 120
+	// gmxPriceDecimals is the precision of GMX prices, which are quoted per unit of a
+	// token's smallest denomination: USD * 10^(30 - token decimals)
+	gmxPriceDecimals = 30
+)
+
+type GMXClient struct {
+	httpClient  *http.Client
+	rateLimiter *RateLimiter
+}
+
+func NewGMXClient() *GMXClient {
+	return &GMXClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		rateLimiter: NewRateLimiter(GMXRateLimit, time.Minute),
+	}
+}
+
+// GMXToken is a token GMX v2 lists, with its oracle price in USD
+type GMXToken struct {
+	Address  string
+	Symbol   string
+	Decimals int
+	// MinPrice and MaxPrice are the oracle's bid and ask, in USD per whole token
+	MinPrice float64
+	MaxPrice float64
+}
+
+// MidPrice is the midpoint of the oracle's bid and ask
+func (t *GMXToken) MidPrice() float64 {
+	return (t.MinPrice + t.MaxPrice) / 2
+}
+
+// GMXMarket is a GMX v2 market; positions reference it by market token
+type GMXMarket struct {
+	MarketToken string `json:"marketToken"`
+	IndexToken  string `json:"indexToken"`
+	LongToken   string `json:"longToken"`
+	ShortToken  string `json:"shortToken"`
+	IsListed    bool   `json:"isListed"`
+}
+
+// GetTokens fetches listed tokens with current oracle prices, keyed by lowercase address
+func (c *GMXClient) GetTokens(ctx context.Context) (map[string]*GMXToken, error) {
+	var tokenList struct {
+		Tokens []struct {
+			Address  string `json:"address"`
+			Symbol   string `json:"symbol"`
+			Decimals int    `json:"decimals"`
+		} `json:"tokens"`
+	}
+	if err := c.get(ctx, "/tokens", &tokenList); err != nil {
+		return nil, err
+	}
+
+	var tickers []struct {
+		TokenAddress string `json:"tokenAddress"`
+		MinPrice     string `json:"minPrice"`
+		MaxPrice     string `json:"maxPrice"`
+	}
+	if err := c.get(ctx, "/prices/tickers", &tickers); err != nil {
+		return nil, err
+	}
+
+	tokens := make(map[string]*GMXToken, len(tokenList.Tokens))
+	for _, t := range tokenList.Tokens {
+		tokens[strings.ToLower(t.Address)] = &GMXToken{
+			Address:  t.Address,
+			Symbol:   t.Symbol,
+			Decimals: t.Decimals,
+		}
+	}
+	for _, ticker := range tickers {
+		token, ok := tokens[strings.ToLower(ticker.TokenAddress)]
+		if !ok {
+			continue
+		}
+		token.MinPrice = gmxPrice(ticker.MinPrice, token.Decimals)
+		token.MaxPrice = gmxPrice(ticker.MaxPrice, token.Decimals)
+	}
+	return tokens, nil
+}
+
+// GetMarkets fetches GMX v2 markets keyed by lowercase market token address
+func (c *GMXClient) GetMarkets(ctx context.Context) (map[string]*GMXMarket, error) {
+	var response struct {
+		Markets []*GMXMarket `json:"markets"`
+	}
+	if err := c.get(ctx, "/markets", &response); err != nil {
+		return nil, err
+	}
+
+	markets := make(map[string]*GMXMarket, len(response.Markets))
+	for _, market := range response.Markets {
+		markets[strings.ToLower(market.MarketToken)] = market
+	}
+	return markets, nil
+}
+
+func (c *GMXClient) get(ctx context.Context, path string, out interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", GMXArbitrumAPIBase+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GMX API error: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// gmxPrice converts a GMX price string to USD per whole token
+func gmxPrice(raw string, tokenDecimals int) float64 {
+	price, ok := new(big.Float).SetString(raw)
+	if !ok {
+		return 0
+	}
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(gmxPriceDecimals-tokenDecimals)), nil))
+	f, _ := price.Quo(price, scale).Float64()
+	return f
+}
@@ -0,0 +1,157 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	HyperliquidAPIBase   = "https://api.hyperliquid.xyz"
+	HyperliquidRateLimit = 600 // info requests weigh 2-20 of the 1200 weight per minute budget
+	// hyperliquidFundingPageSize is the most funding entries the info endpoint returns per call
+	hyperliquidFundingPageSize = 500
+)
+
+type HyperliquidClient struct {
+	httpClient  *http.Client
+	rateLimiter *RateLimiter
+}
+
+func NewHyperliquidClient() *HyperliquidClient {
+	return &HyperliquidClient{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		rateLimiter: NewRateLimiter(HyperliquidRateLimit, time.Minute),
+	}
+}
+
+// decimalString decodes Hyperliquid's string-encoded decimals; null stays zero
+type decimalString float64
+
+func (d *decimalString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid decimal %q: %w", s, err)
+	}
+	*d = decimalString(f)
+	return nil
+}
+
+// HyperliquidPosition is one perpetual position from the clearinghouse state
+type HyperliquidPosition struct {
+	Coin string `json:"coin"`
+	// Size is signed: negative for shorts
+	Size             decimalString  `json:"szi"`
+	EntryPrice       decimalString  `json:"entryPx"`
+	PositionValue    decimalString  `json:"positionValue"`
+	UnrealizedPnL    decimalString  `json:"unrealizedPnl"`
+	LiquidationPrice *decimalString `json:"liquidationPx"`
+	MarginUsed       decimalString  `json:"marginUsed"`
+	Leverage         struct {
+		Type  string  `json:"type"` // cross or isolated
+		Value float64 `json:"value"`
+	} `json:"leverage"`
+	// CumFunding is funding paid by the position (positive when paid, negative when received)
+	CumFunding struct {
+		AllTime     decimalString `json:"allTime"`
+		SinceOpen   decimalString `json:"sinceOpen"`
+		SinceChange decimalString `json:"sinceChange"`
+	} `json:"cumFunding"`
+}
+
+// HyperliquidFunding is one hourly funding settlement for a user
+type HyperliquidFunding struct {
+	Time  int64  `json:"time"` // Unix milliseconds
+	Hash  string `json:"hash"`
+	Delta struct {
+		Coin        string        `json:"coin"`
+		USDC        decimalString `json:"usdc"` // Negative when paid
+		Size        decimalString `json:"szi"`
+		FundingRate decimalString `json:"fundingRate"`
+	} `json:"delta"`
+}
+
+// GetPositions fetches a user's open perpetual positions
+func (c *HyperliquidClient) GetPositions(ctx context.Context, address string) ([]HyperliquidPosition, error) {
+	var state struct {
+		AssetPositions []struct {
+			Position HyperliquidPosition `json:"position"`
+		} `json:"assetPositions"`
+	}
+	if err := c.info(ctx, map[string]interface{}{"type": "clearinghouseState", "user": address}, &state); err != nil {
+		return nil, err
+	}
+
+	positions := make([]HyperliquidPosition, 0, len(state.AssetPositions))
+	for _, asset := range state.AssetPositions {
+		if asset.Position.Size != 0 {
+			positions = append(positions, asset.Position)
+		}
+	}
+	return positions, nil
+}
+
+// GetFunding fetches a user's funding settlements since the given time, oldest first
+func (c *HyperliquidClient) GetFunding(ctx context.Context, address string, since time.Time) ([]HyperliquidFunding, error) {
+	var all []HyperliquidFunding
+	start := since.UnixMilli()
+	for {
+		var page []HyperliquidFunding
+		body := map[string]interface{}{"type": "userFunding", "user": address, "startTime": start}
+		if err := c.info(ctx, body, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < hyperliquidFundingPageSize {
+			return all, nil
+		}
+		start = page[len(page)-1].Time + 1
+	}
+}
+
+// info posts a query to the info endpoint and decodes the response into out
+func (c *HyperliquidClient) info(ctx context.Context, body map[string]interface{}, out interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", HyperliquidAPIBase+"/info", bytes.NewReader(reqBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Hyperliquid API error: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
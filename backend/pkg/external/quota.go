@@ -0,0 +1,78 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Providers whose quota jobs track
+const (
+	ProviderAlchemy   = "alchemy"
+	ProviderCoinGecko = "coingecko"
+)
+
+// defaultQuotaReset is how long a provider is left alone when it doesn't say when its
+// quota resets. Both CoinGecko's and Alchemy's limits are per minute or finer.
+const defaultQuotaReset = time.Minute
+
+// QuotaError is returned when a provider refuses calls until its quota resets
+type QuotaError struct {
+	Provider string
+	// RetryAfter is when the provider said to try again, zero when it didn't
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *QuotaError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+// ResetAfter is how long until the provider's quota resets
+func (e *QuotaError) ResetAfter() time.Duration {
+	if e.RetryAfter > 0 {
+		return e.RetryAfter
+	}
+	return defaultQuotaReset
+}
+
+// AsQuotaError returns the quota error err wraps, if any
+func AsQuotaError(err error) (*QuotaError, bool) {
+	var quotaErr *QuotaError
+	if errors.As(err, &quotaErr) {
+		return quotaErr, true
+	}
+	return nil, false
+}
+
+// RetryAfter reads a Retry-After header given in seconds or as an HTTP date, returning
+// zero when it's missing or in the past
+func RetryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// coinGeckoError describes a failed CoinGecko call, marking an exhausted quota as a
+// QuotaError
+func coinGeckoError(resp *http.Response) error {
+	err := fmt.Errorf("CoinGecko API error: %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &QuotaError{Provider: ProviderCoinGecko, RetryAfter: RetryAfter(resp.Header, time.Now()), Err: err}
+	}
+	return err
+}
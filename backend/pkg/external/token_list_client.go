@@ -0,0 +1,101 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+const (
+	// UniswapTokenListURL is Uniswap's default token list, covering several chains
+	UniswapTokenListURL = "https://tokens.uniswap.org"
+	// CoinGeckoTokenListBase serves CoinGecko's token list of each asset platform
+	CoinGeckoTokenListBase = "https://tokens.coingecko.com"
+	// maxTokenListBytes bounds a list's size; CoinGecko's Ethereum list is the largest
+	// at a few megabytes
+	maxTokenListBytes = 32 << 20
+)
+
+// TokenList is a list in the Uniswap token list format, which CoinGecko's follow too
+type TokenList struct {
+	Name   string           `json:"name"`
+	Tokens []TokenListEntry `json:"tokens"`
+}
+
+// TokenListEntry is a token a list vouches for
+type TokenListEntry struct {
+	ChainID  int    `json:"chainId"`
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals int    `json:"decimals"`
+	LogoURI  string `json:"logoURI"`
+}
+
+// TokenListSource is a token list to read a chain's tokens from
+type TokenListSource struct {
+	Name string
+	URL  string
+}
+
+// TokenListClient fetches standard token lists
+type TokenListClient struct {
+	httpClient    *http.Client
+	uniswapURL    string
+	coinGeckoBase string
+}
+
+func NewTokenListClient() *TokenListClient {
+	return &TokenListClient{
+		httpClient: &http.Client{
+			Timeout:   60 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+		uniswapURL:    UniswapTokenListURL,
+		coinGeckoBase: CoinGeckoTokenListBase,
+	}
+}
+
+// Sources returns the lists to read the chain's tokens from, most trusted first:
+// Uniswap's default list, then CoinGecko's list of the chain's asset platform if it has one
+func (c *TokenListClient) Sources(chainID int) []TokenListSource {
+	sources := []TokenListSource{{Name: "uniswap", URL: c.uniswapURL}}
+	if platform, ok := AssetPlatforms[chainID]; ok {
+		sources = append(sources, TokenListSource{
+			Name: "coingecko",
+			URL:  fmt.Sprintf("%s/%s/all.json", c.coinGeckoBase, platform),
+		})
+	}
+	return sources
+}
+
+// Fetch downloads a token list
+func (c *TokenListClient) Fetch(ctx context.Context, source TokenListSource) (*TokenList, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", source.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s token list: %w", source.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s token list returned status %d", source.Name, resp.StatusCode)
+	}
+
+	var list TokenList
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenListBytes)).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode %s token list: %w", source.Name, err)
+	}
+	return &list, nil
+}
@@ -0,0 +1,44 @@
+package external
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/testutil/vcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenListClient(t *testing.T) {
+	server := vcr.Replay(t, "testdata/tokenlists/lists.json")
+	client := NewTokenListClient()
+	client.uniswapURL = server.URL + "/uniswap"
+	client.coinGeckoBase = server.URL
+	ctx := context.Background()
+
+	sources := client.Sources(137)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "uniswap", sources[0].Name)
+	assert.Equal(t, server.URL+"/polygon-pos/all.json", sources[1].URL)
+	assert.Len(t, client.Sources(8453), 1, "chains without an asset platform only have the Uniswap list")
+
+	list, err := client.Fetch(ctx, sources[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Uniswap Labs Default", list.Name)
+	require.Len(t, list.Tokens, 2)
+	assert.Equal(t, TokenListEntry{
+		ChainID:  1,
+		Address:  "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		Symbol:   "USDC",
+		Name:     "USDCoin",
+		Decimals: 6,
+		LogoURI:  "https://assets.coingecko.com/coins/images/6319/thumb/USD_Coin_icon.png",
+	}, list.Tokens[0])
+
+	list, err = client.Fetch(ctx, sources[1])
+	require.NoError(t, err)
+	assert.Equal(t, "WMATIC", list.Tokens[0].Symbol)
+
+	_, err = client.Fetch(ctx, client.Sources(10)[1])
+	assert.EqualError(t, err, "coingecko token list returned status 404")
+}
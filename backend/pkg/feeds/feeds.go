@@ -0,0 +1,263 @@
+// Package feeds fetches news and announcements from RSS and Atom feeds and from the
+// latest topics of Discourse forums, where most protocols run their governance.
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/defi-dashboard/backend/internal/clients"
+	"github.com/defi-dashboard/backend/pkg/correlation"
+)
+
+// Source kinds
+const (
+	// KindRSS is an RSS 2.0 or Atom feed
+	KindRSS = "rss"
+	// KindDiscourse is a Discourse forum, read through its latest topics
+	KindDiscourse = "discourse"
+)
+
+const (
+	// maxFeedBytes bounds how much of a feed is read
+	maxFeedBytes = 5 << 20
+	// maxSummaryLength is the longest summary kept, in characters
+	maxSummaryLength = 500
+)
+
+// Item is one post or announcement of a feed
+type Item struct {
+	// GUID identifies the item within its feed
+	GUID        string
+	URL         string
+	Title       string
+	Summary     string
+	PublishedAt time.Time
+}
+
+// Fetcher reads feeds over HTTP
+type Fetcher struct {
+	httpClient *http.Client
+}
+
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		httpClient: &http.Client{
+			Timeout:   20 * time.Second,
+			Transport: correlation.NewTransport(clients.EgressTransport()),
+		},
+	}
+}
+
+// Fetch returns the items of a feed of the given kind, newest first as the feed lists
+// them. Items without a date are stamped with now.
+func (f *Fetcher) Fetch(ctx context.Context, kind, url string, now time.Time) ([]Item, error) {
+	switch kind {
+	case KindRSS:
+		body, err := f.get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		return ParseXML(body, now)
+	case KindDiscourse:
+		base := strings.TrimRight(url, "/")
+		body, err := f.get(ctx, base+"/latest.json")
+		if err != nil {
+			return nil, err
+		}
+		return ParseDiscourse(body, base)
+	default:
+		return nil, fmt.Errorf("unknown feed kind %q", kind)
+	}
+}
+
+func (f *Fetcher) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/json, text/xml;q=0.9, */*;q=0.8")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes))
+}
+
+// xmlFeed covers both RSS 2.0 (rss > channel > item) and Atom (feed > entry)
+type xmlFeed struct {
+	XMLName xml.Name
+	Items   []struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		GUID        string `xml:"guid"`
+		Description string `xml:"description"`
+		PubDate     string `xml:"pubDate"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+// ParseXML parses an RSS 2.0 or Atom document
+func ParseXML(data []byte, now time.Time) ([]Item, error) {
+	var feed xmlFeed
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Feeds declare all sorts of encodings; the text we keep is read as is
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var items []Item
+	switch feed.XMLName.Local {
+	case "rss":
+		for _, entry := range feed.Items {
+			item := Item{
+				GUID:        strings.TrimSpace(entry.GUID),
+				URL:         strings.TrimSpace(entry.Link),
+				Title:       cleanText(entry.Title, 0),
+				Summary:     cleanText(entry.Description, maxSummaryLength),
+				PublishedAt: parseDate(entry.PubDate, now),
+			}
+			if item.GUID == "" {
+				item.GUID = item.URL
+			}
+			items = append(items, item)
+		}
+	case "feed":
+		for _, entry := range feed.Entries {
+			item := Item{
+				GUID:    strings.TrimSpace(entry.ID),
+				Title:   cleanText(entry.Title, 0),
+				Summary: cleanText(entry.Summary, maxSummaryLength),
+			}
+			for _, link := range entry.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					item.URL = strings.TrimSpace(link.Href)
+					break
+				}
+			}
+			if item.Summary == "" {
+				item.Summary = cleanText(entry.Content, maxSummaryLength)
+			}
+			published := entry.Published
+			if published == "" {
+				published = entry.Updated
+			}
+			item.PublishedAt = parseDate(published, now)
+			if item.GUID == "" {
+				item.GUID = item.URL
+			}
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: <%s>", feed.XMLName.Local)
+	}
+	return usable(items), nil
+}
+
+// ParseDiscourse parses the latest.json of the Discourse forum at base
+func ParseDiscourse(data []byte, base string) ([]Item, error) {
+	var latest struct {
+		TopicList struct {
+			Topics []struct {
+				ID        int64     `json:"id"`
+				Title     string    `json:"title"`
+				Slug      string    `json:"slug"`
+				Excerpt   string    `json:"excerpt"`
+				CreatedAt time.Time `json:"created_at"`
+			} `json:"topics"`
+		} `json:"topic_list"`
+	}
+	if err := json.Unmarshal(data, &latest); err != nil {
+		return nil, fmt.Errorf("failed to parse Discourse topics: %w", err)
+	}
+
+	items := make([]Item, 0, len(latest.TopicList.Topics))
+	for _, topic := range latest.TopicList.Topics {
+		items = append(items, Item{
+			GUID:        fmt.Sprintf("topic-%d", topic.ID),
+			URL:         fmt.Sprintf("%s/t/%s/%d", base, topic.Slug, topic.ID),
+			Title:       cleanText(topic.Title, 0),
+			Summary:     cleanText(topic.Excerpt, maxSummaryLength),
+			PublishedAt: topic.CreatedAt,
+		})
+	}
+	return usable(items), nil
+}
+
+// usable drops items missing a title or link, which can't be shown
+func usable(items []Item) []Item {
+	kept := items[:0]
+	for _, item := range items {
+		if item.Title != "" && item.URL != "" {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+var (
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// cleanText strips markup from feed text and collapses its whitespace, cutting it at
+// maxLength characters when maxLength is set
+func cleanText(s string, maxLength int) string {
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, " "))
+	s = strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+	if maxLength > 0 && utf8.RuneCountInString(s) > maxLength {
+		s = strings.TrimSpace(string([]rune(s)[:maxLength-1])) + "…"
+	}
+	return s
+}
+
+// dateLayouts are the date formats seen in RSS and Atom feeds
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+// parseDate reads a feed date, falling back to now when it's missing or unreadable
+func parseDate(s string, now time.Time) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return now
+}
@@ -0,0 +1,82 @@
+package feeds
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestParseRSS(t *testing.T) {
+	data, err := os.ReadFile("testdata/blog.rss")
+	require.NoError(t, err)
+
+	items, err := ParseXML(data, testNow)
+	require.NoError(t, err)
+	require.Len(t, items, 2, "items without a title are dropped")
+
+	assert.Equal(t, Item{
+		GUID:        "6611a2b3c4d5e6f708192a3b",
+		URL:         "https://blog.example.org/v4-is-live/",
+		Title:       "V4 is live on mainnet",
+		Summary:     "Today we’re launching V4 . Hooks, singleton pools and more.",
+		PublishedAt: time.Date(2025, 1, 14, 16, 0, 0, 0, time.UTC),
+	}, items[0])
+	assert.Equal(t, "https://blog.example.org/security-review/", items[1].GUID, "the link stands in for a missing guid")
+	assert.Equal(t, "Two audits & a bug bounty.", items[1].Summary)
+	assert.Equal(t, time.Date(2025, 1, 6, 8, 30, 0, 0, time.UTC), items[1].PublishedAt)
+}
+
+func TestParseAtom(t *testing.T) {
+	data, err := os.ReadFile("testdata/releases.atom")
+	require.NoError(t, err)
+
+	items, err := ParseXML(data, testNow)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.Equal(t, "https://github.com/example/protocol/releases/tag/v3.2.0", items[0].URL)
+	assert.Equal(t, "Adds eMode for LSTs", items[0].Summary, "content stands in for a missing summary")
+	assert.Equal(t, time.Date(2025, 2, 3, 11, 22, 33, 0, time.UTC), items[0].PublishedAt)
+	assert.Equal(t, testNow, items[1].PublishedAt, "undated entries are stamped with the fetch time")
+
+	_, err = ParseXML([]byte(`<html><body>Not a feed</body></html>`), testNow)
+	assert.Error(t, err)
+}
+
+func TestFetchDiscourse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/latest.json", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"topic_list":{"topics":[
+			{"id":18452,"title":"[ARFC] Onboard weETH to V3 Base","slug":"arfc-onboard-weeth-to-v3-base","created_at":"2025-02-20T14:05:11.123Z","excerpt":"<p>Proposal to onboard &hellip;</p>"},
+			{"id":18440,"title":"Temp check: reserve factor","slug":"temp-check-reserve-factor","created_at":"2025-02-19T08:00:00.000Z"}
+		]}}`))
+	}))
+	defer server.Close()
+
+	items, err := NewFetcher().Fetch(context.Background(), KindDiscourse, server.URL+"/", testNow)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "topic-18452", items[0].GUID)
+	assert.Equal(t, server.URL+"/t/arfc-onboard-weeth-to-v3-base/18452", items[0].URL)
+	assert.Equal(t, "Proposal to onboard …", items[0].Summary)
+	assert.Equal(t, time.Date(2025, 2, 20, 14, 5, 11, 123000000, time.UTC), items[0].PublishedAt.UTC())
+
+	_, err = NewFetcher().Fetch(context.Background(), "mastodon", server.URL, testNow)
+	assert.Error(t, err)
+}
+
+func TestCleanTextTruncates(t *testing.T) {
+	summary := cleanText(strings.Repeat("word ", 200), maxSummaryLength)
+	assert.Equal(t, maxSummaryLength, len([]rune(summary)))
+	assert.True(t, strings.HasSuffix(summary, "…"))
+}
@@ -0,0 +1,125 @@
+// Package hexutil parses the 0x-prefixed hex quantities returned by JSON-RPC nodes
+// without panicking or silently reading malformed input as zero.
+package hexutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrEmptyString is returned for "", which is not a hex value
+	ErrEmptyString = errors.New("empty hex string")
+	// ErrMissingPrefix is returned for a value without the 0x prefix
+	ErrMissingPrefix = errors.New("hex string without 0x prefix")
+	// ErrEmptyNumber is returned for a bare "0x", as nodes return for calls to an
+	// address without code
+	ErrEmptyNumber = errors.New("hex string \"0x\" has no digits")
+	// ErrSyntax is returned for a value containing a non-hex character
+	ErrSyntax = errors.New("invalid hex string")
+	// ErrBig256Range is returned for a number wider than 256 bits
+	ErrBig256Range = errors.New("hex number larger than 256 bits")
+	// ErrUint64Range is returned for a number that doesn't fit the requested integer type
+	ErrUint64Range = errors.New("hex number out of range")
+)
+
+// Has0xPrefix reports whether s starts with 0x or 0X
+func Has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+// digits returns the hex digits of a 0x-prefixed quantity without leading zeros. Leading
+// zeros are accepted since some methods, e.g. alchemy_getTokenBalances, return 32-byte
+// padded words.
+func digits(s string) (string, error) {
+	if s == "" {
+		return "", ErrEmptyString
+	}
+	if !Has0xPrefix(s) {
+		return "", fmt.Errorf("%w: %q", ErrMissingPrefix, s)
+	}
+	raw := s[2:]
+	if raw == "" {
+		return "", ErrEmptyNumber
+	}
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return "", fmt.Errorf("%w: %q", ErrSyntax, s)
+		}
+	}
+	if trimmed := strings.TrimLeft(raw, "0"); trimmed != "" {
+		return trimmed, nil
+	}
+	return "0", nil
+}
+
+// DecodeBig parses a 0x-prefixed hex quantity of at most 256 bits
+func DecodeBig(s string) (*big.Int, error) {
+	raw, err := digits(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > 64 {
+		return nil, fmt.Errorf("%w: %q", ErrBig256Range, s)
+	}
+	n, ok := new(big.Int).SetString(raw, 16)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSyntax, s)
+	}
+	return n, nil
+}
+
+// DecodeUint64 parses a 0x-prefixed hex quantity that fits in a uint64
+func DecodeUint64(s string) (uint64, error) {
+	raw, err := digits(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) > 16 {
+		return 0, fmt.Errorf("%w: %q", ErrUint64Range, s)
+	}
+	var n uint64
+	for i := 0; i < len(raw); i++ {
+		n = n<<4 | uint64(hexValue(raw[i]))
+	}
+	return n, nil
+}
+
+// DecodeInt64 parses a 0x-prefixed hex quantity that fits in an int64, such as a block
+// number or gas amount
+func DecodeInt64(s string) (int64, error) {
+	n, err := DecodeUint64(s)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q", ErrUint64Range, s)
+	}
+	return int64(n), nil
+}
+
+// EncodeBig formats n as a 0x-prefixed hex quantity
+func EncodeBig(n *big.Int) string {
+	if n == nil || n.Sign() == 0 {
+		return "0x0"
+	}
+	if n.Sign() < 0 {
+		return "-0x" + new(big.Int).Neg(n).Text(16)
+	}
+	return "0x" + n.Text(16)
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,69 @@
+package hexutil
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBig(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   error
+	}{
+		{input: "0x0", want: "0"},
+		{input: "0x1bc16d674ec80000", want: "2000000000000000000"},
+		{input: "0X1BC16D674EC80000", want: "2000000000000000000"},
+		{input: "0x" + strings.Repeat("0", 60) + "0a", want: "10"},
+		{input: "0x" + strings.Repeat("f", 64), want: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)).String()},
+		{input: "", err: ErrEmptyString},
+		{input: "0x", err: ErrEmptyNumber},
+		{input: "1234", err: ErrMissingPrefix},
+		{input: "0xzz", err: ErrSyntax},
+		{input: "0x-1", err: ErrSyntax},
+		{input: "0x1" + strings.Repeat("0", 64), err: ErrBig256Range},
+	}
+
+	for _, tt := range tests {
+		got, err := DecodeBig(tt.input)
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err, tt.input)
+			assert.Nil(t, got, tt.input)
+			continue
+		}
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got.String(), tt.input)
+	}
+}
+
+func TestDecodeUint64(t *testing.T) {
+	n, err := DecodeUint64("0x12d687")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1234567), n)
+
+	n, err = DecodeUint64("0xffffffffffffffff")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<64-1), n)
+
+	_, err = DecodeUint64("0x10000000000000000")
+	assert.ErrorIs(t, err, ErrUint64Range)
+
+	_, err = DecodeInt64("0x8000000000000000")
+	assert.ErrorIs(t, err, ErrUint64Range)
+
+	_, err = DecodeInt64("0x")
+	assert.ErrorIs(t, err, ErrEmptyNumber)
+}
+
+func TestEncodeBig(t *testing.T) {
+	assert.Equal(t, "0x0", EncodeBig(nil))
+	assert.Equal(t, "0x1bc16d674ec80000", EncodeBig(big.NewInt(2000000000000000000)))
+
+	n, err := DecodeBig(EncodeBig(big.NewInt(255)))
+	require.NoError(t, err)
+	assert.Equal(t, int64(255), n.Int64())
+}
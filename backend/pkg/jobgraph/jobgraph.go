@@ -0,0 +1,166 @@
+// Package jobgraph orders scheduled jobs that depend on each other. Jobs declare their
+// cron schedule and the jobs whose output they read; the graph checks the declarations
+// and works out the order jobs due at the same time run in.
+package jobgraph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parser reads cron specs with a leading seconds field, like cron.WithSeconds
+var parser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Job is a scheduled job
+type Job struct {
+	Name string
+	// Schedule is a cron spec with a leading seconds field
+	Schedule    string
+	Description string
+	// DependsOn are the jobs that must finish first when they're due at the same time.
+	// A job is skipped when one of them failed that time.
+	DependsOn []string
+	// Providers are the upstream providers whose quota the job spends. It's held back
+	// while one of them is cooling down.
+	Providers []string
+}
+
+// Graph is a validated set of jobs
+type Graph struct {
+	// jobs are in dependency order: every job comes after its prerequisites
+	jobs      []Job
+	index     map[string]int
+	schedules map[string]cron.Schedule
+	// prerequisites are each job's direct and transitive prerequisites, in dependency
+	// order
+	prerequisites map[string][]string
+	dependents    map[string][]string
+}
+
+// New checks that names are unique, schedules parse and dependencies are known and
+// acyclic. Jobs keep the order given unless a prerequisite has to move ahead.
+func New(jobs []Job) (*Graph, error) {
+	declared := make(map[string]int, len(jobs))
+	schedules := make(map[string]cron.Schedule, len(jobs))
+	for i, job := range jobs {
+		if job.Name == "" {
+			return nil, fmt.Errorf("job %d has no name", i)
+		}
+		if _, ok := declared[job.Name]; ok {
+			return nil, fmt.Errorf("job %s is declared twice", job.Name)
+		}
+		declared[job.Name] = i
+		schedule, err := parser.Parse(job.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %s has an invalid schedule %q: %w", job.Name, job.Schedule, err)
+		}
+		schedules[job.Name] = schedule
+	}
+
+	dependents := make(map[string][]string, len(jobs))
+	waiting := make(map[string]int, len(jobs))
+	for _, job := range jobs {
+		for _, dep := range job.DependsOn {
+			if _, ok := declared[dep]; !ok {
+				return nil, fmt.Errorf("job %s depends on unknown job %s", job.Name, dep)
+			}
+			if dep == job.Name {
+				return nil, fmt.Errorf("job %s depends on itself", job.Name)
+			}
+			dependents[dep] = append(dependents[dep], job.Name)
+			waiting[job.Name]++
+		}
+	}
+
+	// Kahn's algorithm, always taking the earliest declared job that's ready
+	g := &Graph{
+		jobs:          make([]Job, 0, len(jobs)),
+		index:         make(map[string]int, len(jobs)),
+		schedules:     schedules,
+		prerequisites: make(map[string][]string, len(jobs)),
+		dependents:    dependents,
+	}
+	var ready []int
+	for i, job := range jobs {
+		if waiting[job.Name] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		job := jobs[ready[0]]
+		ready = ready[1:]
+		g.index[job.Name] = len(g.jobs)
+		g.jobs = append(g.jobs, job)
+		for _, dependent := range dependents[job.Name] {
+			waiting[dependent]--
+			if waiting[dependent] == 0 {
+				ready = append(ready, declared[dependent])
+			}
+		}
+	}
+	if len(g.jobs) < len(jobs) {
+		var cycle []string
+		for _, job := range jobs {
+			if waiting[job.Name] > 0 {
+				cycle = append(cycle, job.Name)
+			}
+		}
+		return nil, fmt.Errorf("jobs %s depend on each other in a cycle", strings.Join(cycle, ", "))
+	}
+
+	for _, job := range g.jobs {
+		seen := make(map[string]bool)
+		for _, dep := range job.DependsOn {
+			seen[dep] = true
+			for _, transitive := range g.prerequisites[dep] {
+				seen[transitive] = true
+			}
+		}
+		prerequisites := make([]string, 0, len(seen))
+		for name := range seen {
+			prerequisites = append(prerequisites, name)
+		}
+		sort.Slice(prerequisites, func(i, j int) bool { return g.index[prerequisites[i]] < g.index[prerequisites[j]] })
+		g.prerequisites[job.Name] = prerequisites
+	}
+	return g, nil
+}
+
+// Jobs returns the jobs in dependency order
+func (g *Graph) Jobs() []Job {
+	return g.jobs
+}
+
+// Job returns the named job
+func (g *Graph) Job(name string) (Job, bool) {
+	i, ok := g.index[name]
+	if !ok {
+		return Job{}, false
+	}
+	return g.jobs[i], true
+}
+
+// Prerequisites returns the jobs the named job depends on, directly or through other
+// jobs, in dependency order
+func (g *Graph) Prerequisites(name string) []string {
+	return g.prerequisites[name]
+}
+
+// Dependents returns the jobs that depend directly on the named job
+func (g *Graph) Dependents(name string) []string {
+	return g.dependents[name]
+}
+
+// Next returns when the named job is next due after t, or the zero time for unknown jobs
+func (g *Graph) Next(name string, t time.Time) time.Time {
+	schedule, ok := g.schedules[name]
+	if !ok {
+		return time.Time{}
+	}
+	return schedule.Next(t)
+}
\ No newline at end of file
@@ -0,0 +1,70 @@
+package jobgraph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func names(jobs []Job) []string {
+	result := make([]string, len(jobs))
+	for i, job := range jobs {
+		result[i] = job.Name
+	}
+	return result
+}
+
+func TestNewOrdersByDependency(t *testing.T) {
+	g, err := New([]Job{
+		{Name: "alerts", Schedule: "0 */5 * * * *", DependsOn: []string{"valuations", "prices"}},
+		{Name: "valuations", Schedule: "0 */5 * * * *", DependsOn: []string{"prices"}},
+		{Name: "notifications", Schedule: "0 * * * * *"},
+		{Name: "prices", Schedule: "0 */10 * * * *"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"notifications", "prices", "valuations", "alerts"}, names(g.Jobs()))
+	assert.Equal(t, []string{"prices", "valuations"}, g.Prerequisites("alerts"))
+	assert.Equal(t, []string{"prices"}, g.Prerequisites("valuations"))
+	assert.Empty(t, g.Prerequisites("prices"))
+	assert.ElementsMatch(t, []string{"valuations", "alerts"}, g.Dependents("prices"))
+
+	job, ok := g.Job("valuations")
+	require.True(t, ok)
+	assert.Equal(t, "0 */5 * * * *", job.Schedule)
+	_, ok = g.Job("missing")
+	assert.False(t, ok)
+
+	at := time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 10, 0, 0, time.UTC), g.Next("prices", at))
+	assert.True(t, g.Next("missing", at).IsZero())
+}
+
+func TestNewRejectsInvalidGraphs(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs []Job
+		err  string
+	}{
+		{"duplicate", []Job{{Name: "a", Schedule: "@hourly"}, {Name: "a", Schedule: "@daily"}}, "declared twice"},
+		{"unnamed", []Job{{Schedule: "@hourly"}}, "no name"},
+		{"schedule", []Job{{Name: "a", Schedule: "*/5 * * * *"}}, "invalid schedule"},
+		{"unknown", []Job{{Name: "a", Schedule: "@hourly", DependsOn: []string{"b"}}}, "unknown job b"},
+		{"self", []Job{{Name: "a", Schedule: "@hourly", DependsOn: []string{"a"}}}, "itself"},
+		{"cycle", []Job{
+			{Name: "a", Schedule: "@hourly", DependsOn: []string{"c"}},
+			{Name: "b", Schedule: "@hourly", DependsOn: []string{"a"}},
+			{Name: "c", Schedule: "@hourly", DependsOn: []string{"b"}},
+			{Name: "d", Schedule: "@hourly"},
+		}, "jobs a, b, c depend on each other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.jobs)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
\ No newline at end of file
@@ -2,18 +2,27 @@ package logger
 
 import (
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
 
 var log *logrus.Logger
 
+// debugSampleEvery keeps one in every n DebugSampled messages per key
+var (
+	debugSampleEvery atomic.Int64
+	debugSampleCount sync.Map // key -> *atomic.Int64
+)
+
 func init() {
 	log = logrus.New()
 	log.SetFormatter(&logrus.JSONFormatter{
 		TimestampFormat: "2006-01-02 15:04:05",
 	})
 	log.SetOutput(os.Stdout)
+	debugSampleEvery.Store(1)
 }
 
 func Init(level string) {
@@ -25,6 +34,55 @@ func Init(level string) {
 	log.SetLevel(lvl)
 }
 
+// SetLevel changes the level at runtime
+func SetLevel(level string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(lvl)
+	return nil
+}
+
+// Level returns the current level, naming the warning level "warn" as LOG_LEVEL does
+func Level() string {
+	if log.GetLevel() == logrus.WarnLevel {
+		return "warn"
+	}
+	return log.GetLevel().String()
+}
+
+// SetDebugSampling keeps one in every n messages logged with DebugSampled for each
+// key; 1 or less keeps them all
+func SetDebugSampling(n int) {
+	if n < 1 {
+		n = 1
+	}
+	debugSampleEvery.Store(int64(n))
+}
+
+// DebugSampling returns the n set by SetDebugSampling
+func DebugSampling() int {
+	return int(debugSampleEvery.Load())
+}
+
+// DebugSampled logs a high-volume debug message, such as one per token in a batch, only
+// for the first and then every nth call with the same key
+func DebugSampled(key, msg string, fields ...interface{}) {
+	if !log.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+	every := debugSampleEvery.Load()
+	if every > 1 {
+		count, _ := debugSampleCount.LoadOrStore(key, new(atomic.Int64))
+		if (count.(*atomic.Int64).Add(1)-1)%every != 0 {
+			return
+		}
+		fields = append(fields, "sampled", every)
+	}
+	Debug(msg, fields...)
+}
+
 func Debug(msg string, fields ...interface{}) {
 	log.WithFields(parseFields(fields...)).Debug(msg)
 }
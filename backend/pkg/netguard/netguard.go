@@ -0,0 +1,164 @@
+// Package netguard checks URLs supplied by users before the server makes requests to
+// them, so a webhook can't be pointed at the server's own network
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned for a URL or connection reaching an address that isn't
+// on the public internet
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// blockedPrefixes are ranges that aren't public beyond what netip.Addr reports: shared
+// address space, benchmarking, documentation, reserved and the NAT64 prefix, which
+// reaches IPv4 addresses through a translator
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsPublic reports whether ip is a public unicast address
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolver looks up a host's addresses; *net.Resolver is one
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// Policy decides which URLs requests may be made to. The zero value allows https URLs
+// resolving only to public addresses.
+type Policy struct {
+	// AllowInsecure also allows http URLs and private addresses, for development
+	// against receivers running locally
+	AllowInsecure bool
+	// Resolver defaults to net.DefaultResolver
+	Resolver Resolver
+}
+
+// CheckURL parses raw and checks it's an allowed URL whose host resolves only to
+// allowed addresses. The check is repeated when a Client connects, as the host may
+// resolve differently by then.
+func (p Policy) CheckURL(ctx context.Context, raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" && !(p.AllowInsecure && u.Scheme == "http") {
+		return nil, fmt.Errorf("URL must use https")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("URL must not contain credentials")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("URL has no host")
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return u, p.checkAddr(ip)
+	}
+	if strings.EqualFold(strings.TrimSuffix(host, "."), "localhost") && !p.AllowInsecure {
+		return nil, ErrBlockedAddress
+	}
+	ips, err := p.resolver().LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s has no addresses", host)
+	}
+	for _, ip := range ips {
+		if err := p.checkAddr(ip); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+func (p Policy) checkAddr(ip netip.Addr) error {
+	if p.AllowInsecure || IsPublic(ip) {
+		return nil
+	}
+	return ErrBlockedAddress
+}
+
+func (p Policy) resolver() Resolver {
+	if p.Resolver != nil {
+		return p.Resolver
+	}
+	return net.DefaultResolver
+}
+
+// Client returns an HTTP client that only connects to addresses the policy allows,
+// checked on the address actually dialled so a host can't resolve to a public address
+// when checked and a private one when used. It doesn't follow redirects or use a proxy,
+// and gives up after timeout.
+func (p Policy) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("unexpected dial address %s: %w", address, err)
+			}
+			return p.checkAddr(addrPort.Addr())
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ReadLimited reads at most limit bytes of r, failing if there's more
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response larger than %d bytes", limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package pnl
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/amounts"
+)
+
+// BitcoinLots turns Bitcoin transactions into PnL lots: receipts are buys and spends,
+// fee included, are sells at the day's price. Transactions without a price are left
+// out, so the result may understate a history whose prices couldn't be fetched.
+func BitcoinLots(txs []*models.BitcoinTransaction) []models.PnLLot {
+	lots := make([]models.PnLLot, 0, len(txs))
+	for _, tx := range txs {
+		if tx.AmountSats == 0 || tx.PriceUSD == nil {
+			continue
+		}
+
+		lot := models.PnLLot{
+			ID:              tx.ID,
+			TransactionHash: tx.Txid,
+			Type:            "buy",
+			PriceUSD:        fmt.Sprintf("%.10f", *tx.PriceUSD),
+			BlockNumber:     tx.BlockHeight,
+			Timestamp:       tx.Timestamp,
+		}
+		sats := tx.AmountSats
+		if sats < 0 {
+			lot.Type = "sell"
+			sats = -sats
+		}
+		lot.Quantity = amounts.ToUnits(big.NewInt(sats), amounts.BitcoinDecimals).StringFixed(amounts.BitcoinDecimals)
+		lot.RemainingQuantity = lot.Quantity
+		lots = append(lots, lot)
+	}
+	return lots
+}
@@ -0,0 +1,32 @@
+package pnl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinLots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := func(p float64) *float64 { return &p }
+	txs := []*models.BitcoinTransaction{
+		{Txid: "a", AmountSats: 100000000, PriceUSD: price(40000), Timestamp: start},
+		{Txid: "b", AmountSats: 50000000, Timestamp: start.Add(time.Hour)}, // unpriced
+		{Txid: "c", AmountSats: -25000000, PriceUSD: price(60000), Timestamp: start.Add(2 * time.Hour)},
+	}
+
+	lots := BitcoinLots(txs)
+	require.Len(t, lots, 2)
+	assert.Equal(t, "buy", lots[0].Type)
+	assert.Equal(t, "1.00000000", lots[0].Quantity)
+	assert.Equal(t, "sell", lots[1].Type)
+	assert.Equal(t, "0.25000000", lots[1].Quantity)
+
+	calculation, err := NewCalculator(FIFO).CalculatePnL(lots, "50000")
+	require.NoError(t, err)
+	assert.Equal(t, "5000", calculation.RealizedPnLUSD)
+	assert.Equal(t, "0.75", calculation.CurrentQuantity)
+}
@@ -4,9 +4,9 @@ import (
 	"errors"
 	"math/big"
 	"sort"
-	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
 )
 
 type CalculationMethod string
@@ -17,11 +17,19 @@ const (
 )
 
 type Calculator struct {
-	method CalculationMethod
+	method    CalculationMethod
+	washSales *WashSaleRule
+	clock     clock.Clock
 }
 
 func NewCalculator(method CalculationMethod) *Calculator {
-	return &Calculator{method: method}
+	return &Calculator{method: method, clock: clock.System}
+}
+
+// NewCalculatorWithWashSales creates a calculator that applies a jurisdiction's wash-sale
+// rule; a nil rule applies none
+func NewCalculatorWithWashSales(method CalculationMethod, rule *WashSaleRule) *Calculator {
+	return &Calculator{method: method, washSales: rule, clock: clock.System}
 }
 
 // CalculatePnL calculates realized and unrealized PnL for a given set of lots
@@ -45,11 +53,29 @@ func (c *Calculator) CalculatePnL(lots []models.PnLLot, currentPriceUSD string)
 	c.sortLots(sells)
 
 	// Calculate realized PnL from matched lots
-	realizedPnL, processedBuys, err := c.calculateRealizedPnL(buys, sells)
+	realizedPnL, processedBuys, disposals, err := c.calculateRealizedPnL(buys, sells)
 	if err != nil {
 		return nil, err
 	}
 
+	// Flag wash sales, deferring disallowed losses into the replacements' cost basis
+	var washSales []models.WashSale
+	deferredLoss := ""
+	if c.washSales != nil {
+		var deferred *big.Float
+		washSales, deferred, err = c.washSales.detect(disposals, processedBuys)
+		if err != nil {
+			return nil, err
+		}
+		if c.washSales.DeferLoss {
+			deferredLoss = deferred.String()
+			realizedPnL, err = c.addDecimals(realizedPnL, deferredLoss)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Calculate unrealized PnL from remaining buy lots
 	unrealizedPnL, totalCostBasis, currentQuantity, err := c.calculateUnrealizedPnL(processedBuys, currentPriceUSD)
 	if err != nil {
@@ -75,7 +101,7 @@ func (c *Calculator) CalculatePnL(lots []models.PnLLot, currentPriceUSD string)
 		// since lots don't contain wallet/token addresses directly
 	}
 
-	return &models.PnLCalculation{
+	calculation := &models.PnLCalculation{
 		WalletAddress:     walletAddress,
 		TokenAddress:      tokenAddress,
 		TokenSymbol:       tokenSymbol,
@@ -87,8 +113,14 @@ func (c *Calculator) CalculatePnL(lots []models.PnLLot, currentPriceUSD string)
 		CurrentValueUSD:   currentValue,
 		CurrentQuantity:   currentQuantity,
 		Lots:              processedBuys,
-		CalculatedAt:      time.Now(),
-	}, nil
+		WashSales:         washSales,
+		DeferredLossUSD:   deferredLoss,
+		CalculatedAt:      c.clock.Now(),
+	}
+	if c.washSales != nil {
+		calculation.Jurisdiction = c.washSales.Jurisdiction
+	}
+	return calculation, nil
 }
 
 // sortLots sorts lots based on the calculation method
@@ -106,25 +138,28 @@ func (c *Calculator) sortLots(lots []models.PnLLot) {
 	}
 }
 
-// calculateRealizedPnL matches sell lots against buy lots to calculate realized PnL
-func (c *Calculator) calculateRealizedPnL(buys, sells []models.PnLLot) (string, []models.PnLLot, error) {
+// calculateRealizedPnL matches sell lots against buy lots to calculate realized PnL,
+// returning each sale's matches for wash-sale detection
+func (c *Calculator) calculateRealizedPnL(buys, sells []models.PnLLot) (string, []models.PnLLot, []disposal, error) {
 	// Create copies to avoid modifying originals
 	buysCopy := make([]models.PnLLot, len(buys))
 	copy(buysCopy, buys)
 
 	totalRealizedPnL := "0"
+	var disposals []disposal
 
 	for _, sell := range sells {
 		sellQuantity, err := c.stringToBigFloat(sell.Quantity)
 		if err != nil {
-			return "", nil, err
+			return "", nil, nil, err
 		}
 
 		remainingSellQuantity := new(big.Float).Set(sellQuantity)
 		sellPrice, err := c.stringToBigFloat(sell.PriceUSD)
 		if err != nil {
-			return "", nil, err
+			return "", nil, nil, err
 		}
+		sale := disposal{sell: sell, quantity: new(big.Float), pnl: new(big.Float), consumed: make(map[int]bool)}
 
 		// Match against buy lots
 		for i := range buysCopy {
@@ -134,16 +169,16 @@ func (c *Calculator) calculateRealizedPnL(buys, sells []models.PnLLot) (string,
 
 			buyRemaining, err := c.stringToBigFloat(buysCopy[i].RemainingQuantity)
 			if err != nil {
-				return "", nil, err
+				return "", nil, nil, err
 			}
 
 			if buyRemaining.Cmp(big.NewFloat(0)) <= 0 {
 				continue
 			}
 
-			buyPrice, err := c.stringToBigFloat(buysCopy[i].PriceUSD)
+			buyPrice, err := c.stringToBigFloat(lotCost(buysCopy[i]))
 			if err != nil {
-				return "", nil, err
+				return "", nil, nil, err
 			}
 
 			// Calculate quantity to match
@@ -160,22 +195,34 @@ func (c *Calculator) calculateRealizedPnL(buys, sells []models.PnLLot) (string,
 			newRemaining := new(big.Float).Sub(buyRemaining, matchedQuantity)
 			buysCopy[i].RemainingQuantity = newRemaining.String()
 
+			// Moving tokens to another of the user's wallets isn't a disposal
+			if sell.InternalTransferID != nil {
+				continue
+			}
+
 			// Calculate realized PnL for this match
 			costBasis := new(big.Float).Mul(matchedQuantity, buyPrice)
 			proceeds := new(big.Float).Mul(matchedQuantity, sellPrice)
 			pnl := new(big.Float).Sub(proceeds, costBasis)
+			sale.quantity.Add(sale.quantity, matchedQuantity)
+			sale.pnl.Add(sale.pnl, pnl)
+			sale.consumed[i] = true
 
 			// Add to total realized PnL
 			currentTotal, err := c.stringToBigFloat(totalRealizedPnL)
 			if err != nil {
-				return "", nil, err
+				return "", nil, nil, err
 			}
 			currentTotal.Add(currentTotal, pnl)
 			totalRealizedPnL = currentTotal.String()
 		}
+
+		if sell.InternalTransferID == nil {
+			disposals = append(disposals, sale)
+		}
 	}
 
-	return totalRealizedPnL, buysCopy, nil
+	return totalRealizedPnL, buysCopy, disposals, nil
 }
 
 // calculateUnrealizedPnL calculates unrealized PnL from remaining buy lots
@@ -199,7 +246,7 @@ func (c *Calculator) calculateUnrealizedPnL(buys []models.PnLLot, currentPriceUS
 			continue
 		}
 
-		buyPrice, err := c.stringToBigFloat(buy.PriceUSD)
+		buyPrice, err := c.stringToBigFloat(lotCost(buy))
 		if err != nil {
 			return "", "", "", err
 		}
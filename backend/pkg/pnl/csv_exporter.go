@@ -10,20 +10,22 @@ import (
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/clock"
 )
 
 type CSVExporter struct {
 	tempDir string
+	clock   clock.Clock
 }
 
 func NewCSVExporter(tempDir string) *CSVExporter {
-	return &CSVExporter{tempDir: tempDir}
+	return &CSVExporter{tempDir: tempDir, clock: clock.System}
 }
 
 // ExportToCSV exports PnL data to a CSV file and returns the file path
 func (e *CSVExporter) ExportToCSV(data []models.PnLExportData, walletAddress string) (string, error) {
 	// Create temporary file
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := e.clock.Now().Format("20060102_150405")
 	filename := fmt.Sprintf("pnl_export_%s_%s.csv", walletAddress[:8], timestamp)
 	filepath := filepath.Join(e.tempDir, filename)
 
@@ -50,6 +52,8 @@ func (e *CSVExporter) ExportToCSV(data []models.PnLExportData, walletAddress str
 		"Realized PnL USD",
 		"Timestamp",
 		"Block Number",
+		"Wash Sale",
+		"Wash Sale Adjustment USD",
 	}
 
 	if err := writer.Write(header); err != nil {
@@ -70,6 +74,8 @@ func (e *CSVExporter) ExportToCSV(data []models.PnLExportData, walletAddress str
 			row.RealizedPnLUSD,
 			row.Timestamp.Format("2006-01-02 15:04:05"),
 			strconv.FormatInt(row.BlockNumber, 10),
+			strconv.FormatBool(row.WashSale),
+			row.WashSaleAdjustmentUSD,
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -98,6 +104,8 @@ func (e *CSVExporter) ExportToWriter(writer io.Writer, data []models.PnLExportDa
 		"Realized PnL USD",
 		"Timestamp",
 		"Block Number",
+		"Wash Sale",
+		"Wash Sale Adjustment USD",
 	}
 
 	if err := csvWriter.Write(header); err != nil {
@@ -118,6 +126,8 @@ func (e *CSVExporter) ExportToWriter(writer io.Writer, data []models.PnLExportDa
 			row.RealizedPnLUSD,
 			row.Timestamp.Format("2006-01-02 15:04:05"),
 			strconv.FormatInt(row.BlockNumber, 10),
+			strconv.FormatBool(row.WashSale),
+			row.WashSaleAdjustmentUSD,
 		}
 
 		if err := csvWriter.Write(record); err != nil {
@@ -0,0 +1,36 @@
+package pnl
+
+import (
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// CalculateDerivativesPnL combines the unrealized PnL of open perpetual positions with the
+// funding settled in the period. Venues fold funding into unrealized PnL differently, so
+// funding is reported separately and added once to the total.
+func CalculateDerivativesPnL(positions []*models.DerivativePosition, payments []models.FundingPayment) *models.DerivativesPnLSummary {
+	summary := &models.DerivativesPnLSummary{
+		Positions: positions,
+	}
+	if summary.Positions == nil {
+		summary.Positions = []*models.DerivativePosition{}
+	}
+
+	for _, position := range positions {
+		summary.UnrealizedPnLUSD += position.UnrealizedPnLUSD
+		if position.IsOpen {
+			summary.OpenPositions++
+		}
+	}
+
+	for _, payment := range payments {
+		if payment.AmountUSD < 0 {
+			summary.FundingPaidUSD -= payment.AmountUSD
+		} else {
+			summary.FundingReceivedUSD += payment.AmountUSD
+		}
+	}
+	summary.NetFundingUSD = summary.FundingReceivedUSD - summary.FundingPaidUSD
+	summary.TotalPnLUSD = summary.UnrealizedPnLUSD + summary.NetFundingUSD
+
+	return summary
+}
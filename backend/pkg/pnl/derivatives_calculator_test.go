@@ -0,0 +1,41 @@
+package pnl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateDerivativesPnL(t *testing.T) {
+	now := time.Now()
+	positions := []*models.DerivativePosition{
+		{Venue: models.DerivativeVenueHyperliquid, Market: "ETH", Side: models.DerivativeSideLong, UnrealizedPnLUSD: 250, IsOpen: true},
+		{Venue: models.DerivativeVenueGMXV2, Market: "0xmarket", Side: models.DerivativeSideShort, UnrealizedPnLUSD: -100, IsOpen: true},
+	}
+	payments := []models.FundingPayment{
+		{Venue: models.DerivativeVenueHyperliquid, Market: "ETH", AmountUSD: -12.5, PaidAt: now.Add(-2 * time.Hour)},
+		{Venue: models.DerivativeVenueHyperliquid, Market: "ETH", AmountUSD: -7.5, PaidAt: now.Add(-time.Hour)},
+		{Venue: models.DerivativeVenueHyperliquid, Market: "BTC", AmountUSD: 5, PaidAt: now},
+	}
+
+	summary := CalculateDerivativesPnL(positions, payments)
+	require.NotNil(t, summary)
+
+	assert.Equal(t, 2, summary.OpenPositions)
+	assert.InDelta(t, 150.0, summary.UnrealizedPnLUSD, 1e-9)
+	assert.InDelta(t, 20.0, summary.FundingPaidUSD, 1e-9)
+	assert.InDelta(t, 5.0, summary.FundingReceivedUSD, 1e-9)
+	assert.InDelta(t, -15.0, summary.NetFundingUSD, 1e-9)
+	assert.InDelta(t, 135.0, summary.TotalPnLUSD, 1e-9)
+}
+
+func TestCalculateDerivativesPnL_Empty(t *testing.T) {
+	summary := CalculateDerivativesPnL(nil, nil)
+	require.NotNil(t, summary)
+	assert.Equal(t, 0, summary.OpenPositions)
+	assert.Zero(t, summary.TotalPnLUSD)
+	assert.NotNil(t, summary.Positions)
+}
@@ -0,0 +1,68 @@
+package pnl
+
+import (
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// ExchangeLots turns exchange trades into PnL lots for one asset. A trade moves both of
+// its assets, so buying ETH with BTC is an ETH buy and a BTC sell. Fees paid in the asset
+// traded change the quantity, fees in the quote asset the cost or proceeds. Trades whose
+// quote asset couldn't be priced in USD are left out.
+func ExchangeLots(trades []*models.ExchangeTrade, asset string) []models.PnLLot {
+	lots := make([]models.PnLLot, 0, len(trades))
+	for _, trade := range trades {
+		if trade.QuotePriceUSD == nil || trade.Quantity <= 0 || trade.Price <= 0 {
+			continue
+		}
+		buy := trade.Side == "buy"
+
+		baseAmount, quoteAmount := trade.Quantity, trade.Quantity*trade.Price
+		switch trade.FeeAsset {
+		case trade.BaseAsset:
+			if buy {
+				baseAmount -= trade.Fee
+			} else {
+				baseAmount += trade.Fee
+			}
+		case trade.QuoteAsset:
+			if buy {
+				quoteAmount += trade.Fee
+			} else {
+				quoteAmount -= trade.Fee
+			}
+		}
+		if baseAmount <= 0 || quoteAmount <= 0 {
+			continue
+		}
+		quoteUSD := *trade.QuotePriceUSD
+
+		var lot models.PnLLot
+		switch asset {
+		case trade.BaseAsset:
+			lot.Quantity = fmt.Sprintf("%.18f", baseAmount)
+			lot.PriceUSD = fmt.Sprintf("%.10f", quoteAmount*quoteUSD/baseAmount)
+			lot.Type = "sell"
+			if buy {
+				lot.Type = "buy"
+			}
+		case trade.QuoteAsset:
+			lot.Quantity = fmt.Sprintf("%.18f", quoteAmount)
+			lot.PriceUSD = fmt.Sprintf("%.10f", quoteUSD)
+			lot.Type = "buy"
+			if buy {
+				lot.Type = "sell"
+			}
+		default:
+			continue
+		}
+
+		lot.ID = trade.ID
+		lot.TransactionHash = fmt.Sprintf("%s:%s", trade.Exchange, trade.TradeID)
+		lot.RemainingQuantity = lot.Quantity
+		lot.Timestamp = trade.ExecutedAt
+		lots = append(lots, lot)
+	}
+	return lots
+}
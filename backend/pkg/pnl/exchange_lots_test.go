@@ -0,0 +1,57 @@
+package pnl
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeLots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := func(p float64) *float64 { return &p }
+	trades := []*models.ExchangeTrade{
+		// 1.001 BTC for 40040 USDT, 0.001 BTC of it taken as fee
+		{TradeID: "1", BaseAsset: "BTC", QuoteAsset: "USDT", Side: "buy", Quantity: 1.001, Price: 40000,
+			Fee: 0.001, FeeAsset: "BTC", QuotePriceUSD: price(1), ExecutedAt: start},
+		// 10 ETH for 0.5 BTC
+		{TradeID: "2", BaseAsset: "ETH", QuoteAsset: "BTC", Side: "buy", Quantity: 10, Price: 0.05,
+			QuotePriceUSD: price(50000), ExecutedAt: start.Add(time.Hour)},
+		// Unpriced quote asset
+		{TradeID: "3", BaseAsset: "BTC", QuoteAsset: "XYZ", Side: "buy", Quantity: 1, Price: 100,
+			ExecutedAt: start.Add(2 * time.Hour)},
+		// 0.25 BTC for 15000 USD less a 100 USD fee
+		{TradeID: "4", BaseAsset: "BTC", QuoteAsset: "USD", Side: "sell", Quantity: 0.25, Price: 60000,
+			Fee: 100, FeeAsset: "USD", QuotePriceUSD: price(1), ExecutedAt: start.Add(3 * time.Hour)},
+	}
+
+	lots := ExchangeLots(trades, "BTC")
+	require.Len(t, lots, 3)
+	assert.Equal(t, "buy", lots[0].Type)
+	assert.InDelta(t, 40040, parseFloat(t, lots[0].PriceUSD), 1e-6)
+	assert.Equal(t, "sell", lots[1].Type) // BTC spent on ETH
+	assert.InDelta(t, 0.5, parseFloat(t, lots[1].Quantity), 1e-12)
+	assert.Equal(t, "sell", lots[2].Type)
+	assert.InDelta(t, 59600, parseFloat(t, lots[2].PriceUSD), 1e-6)
+
+	calculation, err := NewCalculator(FIFO).CalculatePnL(lots, "50000")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, parseFloat(t, calculation.CurrentQuantity), 1e-12)
+	// 0.5 BTC moved into ETH at 50000 and 0.25 BTC sold at 59600, both bought at 40040
+	assert.InDelta(t, 4980+4890, parseFloat(t, calculation.RealizedPnLUSD), 1e-6)
+
+	ethLots := ExchangeLots(trades, "ETH")
+	require.Len(t, ethLots, 1)
+	assert.Equal(t, "buy", ethLots[0].Type)
+	assert.InDelta(t, 2500, parseFloat(t, ethLots[0].PriceUSD), 1e-6)
+}
+
+func parseFloat(t *testing.T, s string) float64 {
+	t.Helper()
+	f, err := strconv.ParseFloat(s, 64)
+	require.NoError(t, err)
+	return f
+}
@@ -0,0 +1,158 @@
+package pnl
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+)
+
+// IncomeCandidate is a lot acquired by an inbound transfer, with what is known about the
+// transfer for classifying it as income
+type IncomeCandidate struct {
+	Lot           models.PnLLot
+	TokenSymbol   string
+	TokenAddress  string
+	WalletAddress string
+	FromAddress   string
+	ToAddress     *string
+	Metadata      map[string]interface{}
+	Override      *string // the user's category, if they have classified the transfer
+}
+
+// incomeContracts maps reward and distributor contracts, by chain and lowercase address,
+// to the income category of what they pay out
+var incomeContracts = map[int]map[string]string{
+	1: {
+		"0x090d4613473dee047c3f2706764f49e0821d256e": models.IncomeAirdrop,       // Uniswap UNI merkle distributor
+		"0x3d9819210a31b4961b30ef54be2aed79b9c9cd3b": models.IncomeInterest,      // Compound comptroller (COMP)
+		"0xd784927ff2f95ba542bfc824c8a8a98f3495f6b5": models.IncomeInterest,      // Aave v2 incentives controller
+		"0xd061d61a4d941c39e5453435b6345dc261c2fce0": models.IncomeStakingReward, // Curve CRV minter
+	},
+}
+
+// incomeMethods maps fragments of lowercased method names to income categories, checked
+// in order so the more specific fragments win
+var incomeMethods = []struct {
+	fragment string
+	category string
+}{
+	{"airdrop", models.IncomeAirdrop},
+	{"cashback", models.IncomeCashback},
+	{"interest", models.IncomeInterest},
+	{"claimcomp", models.IncomeInterest},
+	{"reward", models.IncomeStakingReward},
+	{"harvest", models.IncomeStakingReward},
+}
+
+// IsIncomeCategory reports whether category names an income category
+func IsIncomeCategory(category string) bool {
+	switch category {
+	case models.IncomeStakingReward, models.IncomeAirdrop, models.IncomeInterest,
+		models.IncomeCashback, models.IncomeMining, models.IncomeOther:
+		return true
+	}
+	return false
+}
+
+// ClassifyIncome returns the income category of an inbound transfer and how it was
+// decided, or "" if it isn't income. A user override wins, then the type given for a
+// manual transaction, then the contract paying out and finally the method called.
+// Transfers matching none of these are ordinary acquisitions.
+func ClassifyIncome(candidate *IncomeCandidate) (category, source string) {
+	if candidate.Override != nil {
+		if *candidate.Override == models.IncomeNotIncome {
+			return "", models.IncomeSourceOverride
+		}
+		return *candidate.Override, models.IncomeSourceOverride
+	}
+
+	if candidate.Lot.Source == models.SourceManual {
+		if manualType, _ := candidate.Metadata["manual_type"].(string); manualType != models.ManualTypeIncome {
+			return "", ""
+		}
+		category, _ := candidate.Metadata["income_category"].(string)
+		if !IsIncomeCategory(category) {
+			category = models.IncomeOther
+		}
+		return category, models.IncomeSourceManual
+	}
+
+	// The wallet either received from the contract or called it to claim
+	counterparties := []string{candidate.FromAddress}
+	if candidate.ToAddress != nil {
+		counterparties = append(counterparties, *candidate.ToAddress)
+	}
+	for _, address := range counterparties {
+		address = strings.ToLower(address)
+		if address == strings.ToLower(candidate.WalletAddress) {
+			continue
+		}
+		if category, ok := incomeContracts[candidate.Lot.ChainID][address]; ok {
+			return category, models.IncomeSourceProtocol
+		}
+	}
+
+	method, _ := candidate.Metadata["functionName"].(string)
+	method = strings.ToLower(method)
+	name := method
+	if i := strings.Index(name, "("); i >= 0 {
+		name = name[:i]
+	}
+	for _, m := range incomeMethods {
+		if strings.Contains(name, m.fragment) {
+			return m.category, models.IncomeSourceMethod
+		}
+	}
+	// Merkle distributors pay airdrops out of claim(index, account, amount, proof)
+	if strings.HasPrefix(name, "claim") && strings.Contains(method, "bytes32[]") {
+		return models.IncomeAirdrop, models.IncomeSourceMethod
+	}
+
+	return "", ""
+}
+
+// CalculateIncome classifies candidates and totals those that are income at their fair
+// market value on receipt, which is the lot's price
+func CalculateIncome(candidates []IncomeCandidate) *models.IncomeSummary {
+	summary := &models.IncomeSummary{
+		ByCategory: make(map[string]decimal.Decimal),
+		Events:     []models.IncomeEvent{},
+	}
+
+	for i := range candidates {
+		candidate := &candidates[i]
+		category, source := ClassifyIncome(candidate)
+		if category == "" {
+			continue
+		}
+
+		quantity, _ := strconv.ParseFloat(candidate.Lot.Quantity, 64)
+		price, _ := decimal.Parse(candidate.Lot.PriceUSD)
+		value := price
+		if q, err := decimal.Parse(candidate.Lot.Quantity); err == nil {
+			value = q.Mul(price)
+		}
+		event := models.IncomeEvent{
+			TransactionHash: candidate.Lot.TransactionHash,
+			TokenSymbol:     candidate.TokenSymbol,
+			TokenAddress:    candidate.TokenAddress,
+			Quantity:        quantity,
+			PriceUSD:        price,
+			ValueUSD:        value,
+			Category:        category,
+			ClassifiedBy:    source,
+			Timestamp:       candidate.Lot.Timestamp,
+		}
+		summary.Events = append(summary.Events, event)
+		summary.ByCategory[category] = summary.ByCategory[category].Add(event.ValueUSD)
+		summary.TotalUSD = summary.TotalUSD.Add(event.ValueUSD)
+	}
+
+	sort.SliceStable(summary.Events, func(i, j int) bool {
+		return summary.Events[i].Timestamp.Before(summary.Events[j].Timestamp)
+	})
+	return summary
+}
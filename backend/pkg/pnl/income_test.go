@@ -0,0 +1,155 @@
+package pnl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const incomeWallet = "0x1234567890123456789012345678901234567890"
+
+func incomeCandidate(hash, from, quantity, price string, metadata map[string]interface{}) IncomeCandidate {
+	return IncomeCandidate{
+		Lot: models.PnLLot{
+			TransactionHash: hash,
+			ChainID:         1,
+			Type:            "buy",
+			Quantity:        quantity,
+			PriceUSD:        price,
+			Source:          models.SourceOnchain,
+			Timestamp:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		TokenSymbol:   "TKN",
+		WalletAddress: incomeWallet,
+		FromAddress:   from,
+		Metadata:      metadata,
+	}
+}
+
+func TestClassifyIncome(t *testing.T) {
+	stranger := "0x0987654321098765432109876543210987654321"
+	notIncome := models.IncomeNotIncome
+	interest := models.IncomeInterest
+	distributor := "0x090D4613473dEE047c3f2706764f49E0821D256e"
+
+	tests := []struct {
+		name     string
+		build    func() IncomeCandidate
+		category string
+		source   string
+	}{
+		{
+			name: "plain transfer",
+			build: func() IncomeCandidate {
+				return incomeCandidate("0x1", stranger, "1", "1", nil)
+			},
+		},
+		{
+			name: "known distributor",
+			build: func() IncomeCandidate {
+				return incomeCandidate("0x2", distributor, "1", "1", nil)
+			},
+			category: models.IncomeAirdrop,
+			source:   models.IncomeSourceProtocol,
+		},
+		{
+			name: "claim sent to a known contract",
+			build: func() IncomeCandidate {
+				c := incomeCandidate("0x3", incomeWallet, "1", "1", nil)
+				comptroller := "0x3d9819210a31b4961b30ef54be2aed79b9c9cd3b"
+				c.ToAddress = &comptroller
+				return c
+			},
+			category: models.IncomeInterest,
+			source:   models.IncomeSourceProtocol,
+		},
+		{
+			name: "reward method",
+			build: func() IncomeCandidate {
+				return incomeCandidate("0x4", stranger, "1", "1", map[string]interface{}{"functionName": "getReward()"})
+			},
+			category: models.IncomeStakingReward,
+			source:   models.IncomeSourceMethod,
+		},
+		{
+			name: "merkle claim",
+			build: func() IncomeCandidate {
+				return incomeCandidate("0x5", stranger, "1", "1", map[string]interface{}{"functionName": "claim(uint256,address,uint256,bytes32[])"})
+			},
+			category: models.IncomeAirdrop,
+			source:   models.IncomeSourceMethod,
+		},
+		{
+			name: "override excludes a heuristic match",
+			build: func() IncomeCandidate {
+				c := incomeCandidate("0x6", distributor, "1", "1", nil)
+				c.Override = &notIncome
+				return c
+			},
+			source: models.IncomeSourceOverride,
+		},
+		{
+			name: "override classifies a plain transfer",
+			build: func() IncomeCandidate {
+				c := incomeCandidate("0x7", stranger, "1", "1", nil)
+				c.Override = &interest
+				return c
+			},
+			category: models.IncomeInterest,
+			source:   models.IncomeSourceOverride,
+		},
+		{
+			name: "manual income",
+			build: func() IncomeCandidate {
+				c := incomeCandidate("manual-1", incomeWallet, "1", "1", map[string]interface{}{
+					"manual_type":     models.ManualTypeIncome,
+					"income_category": models.IncomeMining,
+				})
+				c.Lot.Source = models.SourceManual
+				return c
+			},
+			category: models.IncomeMining,
+			source:   models.IncomeSourceManual,
+		},
+		{
+			name: "manual gift",
+			build: func() IncomeCandidate {
+				c := incomeCandidate("manual-2", incomeWallet, "1", "1", map[string]interface{}{"manual_type": models.ManualTypeGiftIn})
+				c.Lot.Source = models.SourceManual
+				return c
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := tt.build()
+			category, source := ClassifyIncome(&candidate)
+			assert.Equal(t, tt.category, category)
+			assert.Equal(t, tt.source, source)
+		})
+	}
+}
+
+func TestCalculateIncome(t *testing.T) {
+	stranger := "0x0987654321098765432109876543210987654321"
+	reward := incomeCandidate("0x1", stranger, "2", "10", map[string]interface{}{"functionName": "claimRewards"})
+	airdrop := incomeCandidate("0x2", "0x090d4613473dee047c3f2706764f49e0821d256e", "400", "3.5", nil)
+	airdrop.Lot.Timestamp = reward.Lot.Timestamp.Add(-time.Hour)
+	transfer := incomeCandidate("0x3", stranger, "5", "100", nil)
+
+	summary := CalculateIncome([]IncomeCandidate{reward, airdrop, transfer})
+	require.Len(t, summary.Events, 2)
+	assert.Equal(t, "0x2", summary.Events[0].TransactionHash, "events are in time order")
+	assert.Equal(t, "1400", summary.Events[0].ValueUSD.String())
+	assert.Equal(t, "20", summary.ByCategory[models.IncomeStakingReward].String())
+	assert.Equal(t, "1400", summary.ByCategory[models.IncomeAirdrop].String())
+	assert.Equal(t, "1420", summary.TotalUSD.String())
+
+	empty := CalculateIncome(nil)
+	assert.NotNil(t, empty.Events)
+	assert.True(t, empty.TotalUSD.IsZero())
+}
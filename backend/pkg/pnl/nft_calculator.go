@@ -0,0 +1,86 @@
+package pnl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// CalculateNFTPnL builds per-unit cost-basis lots from NFT transfers and matches
+// disposals against acquisitions FIFO per (contract, token ID).
+// Transfers without a priced consideration (gifts, airdrops) count as zero cost/proceeds.
+// transfers should cover the wallet's whole history so earlier acquisitions are matched;
+// only disposals at or after from are reported, along with the lots still held.
+func CalculateNFTPnL(transfers []models.NFTTransfer, from time.Time) *models.NFTPnLSummary {
+	sorted := make([]models.NFTTransfer, len(transfers))
+	copy(sorted, transfers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	open := make(map[string][]int) // contract:tokenID -> indexes of held lots, oldest first
+	var lots []models.NFTLot
+
+	for _, transfer := range sorted {
+		key := transfer.ContractAddress + ":" + transfer.TokenID
+		quantity := transfer.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+
+		unitValue := 0.0
+		if transfer.ValueUSD != nil {
+			unitValue = *transfer.ValueUSD / float64(quantity)
+		}
+
+		switch transfer.Direction {
+		case "in":
+			for i := int64(0); i < quantity; i++ {
+				lots = append(lots, models.NFTLot{
+					ContractAddress: transfer.ContractAddress,
+					TokenID:         transfer.TokenID,
+					AcquiredTxHash:  transfer.TransactionHash,
+					AcquiredAt:      transfer.Timestamp,
+					CostUSD:         unitValue,
+				})
+				open[key] = append(open[key], len(lots)-1)
+			}
+		case "out":
+			for i := int64(0); i < quantity && len(open[key]) > 0; i++ {
+				idx := open[key][0]
+				open[key] = open[key][1:]
+
+				hash := transfer.TransactionHash
+				disposedAt := transfer.Timestamp
+				proceeds := unitValue
+				realized := proceeds - lots[idx].CostUSD
+
+				lots[idx].DisposedTxHash = &hash
+				lots[idx].DisposedAt = &disposedAt
+				lots[idx].ProceedsUSD = &proceeds
+				lots[idx].RealizedPnLUSD = &realized
+			}
+		}
+	}
+
+	summary := &models.NFTPnLSummary{}
+	for _, lot := range lots {
+		if lot.DisposedAt != nil && lot.DisposedAt.Before(from) {
+			continue
+		}
+		summary.Lots = append(summary.Lots, lot)
+		if lot.RealizedPnLUSD != nil {
+			summary.RealizedPnLUSD += *lot.RealizedPnLUSD
+			summary.DisposedCount++
+		} else {
+			summary.CostBasisHeldUSD += lot.CostUSD
+			summary.HeldCount++
+		}
+	}
+	if summary.Lots == nil {
+		summary.Lots = []models.NFTLot{}
+	}
+
+	return summary
+}
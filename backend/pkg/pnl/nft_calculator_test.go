@@ -0,0 +1,72 @@
+package pnl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateNFTPnL(t *testing.T) {
+	base := time.Now().Add(-time.Hour * 72)
+	cost := 1000.0
+	proceeds := 1500.0
+	batchCost := 90.0
+
+	transfers := []models.NFTTransfer{
+		// Sold out of order in the slice to make sure transfers are sorted by time
+		{ContractAddress: "0xpunks", TokenID: "1", Direction: "out", Quantity: 1, ValueUSD: &proceeds, TransactionHash: "0xsell", Timestamp: base.Add(time.Hour * 24)},
+		{ContractAddress: "0xpunks", TokenID: "1", Direction: "in", Quantity: 1, ValueUSD: &cost, TransactionHash: "0xbuy", Timestamp: base},
+		{ContractAddress: "0xgame", TokenID: "7", Direction: "in", Quantity: 3, ValueUSD: &batchCost, TransactionHash: "0xbatch", Timestamp: base},
+		{ContractAddress: "0xgame", TokenID: "7", Direction: "out", Quantity: 1, TransactionHash: "0xgift", Timestamp: base.Add(time.Hour)},
+	}
+
+	summary := CalculateNFTPnL(transfers, time.Time{})
+	require.NotNil(t, summary)
+
+	// 1500 - 1000 on the 721 sale, 0 - 30 on the gifted 1155 unit
+	assert.InDelta(t, 470.0, summary.RealizedPnLUSD, 1e-9)
+	assert.Equal(t, 2, summary.DisposedCount)
+	assert.Equal(t, 2, summary.HeldCount)
+	assert.InDelta(t, 60.0, summary.CostBasisHeldUSD, 1e-9)
+	assert.Len(t, summary.Lots, 4)
+}
+
+func TestCalculateNFTPnL_Empty(t *testing.T) {
+	summary := CalculateNFTPnL(nil, time.Time{})
+	require.NotNil(t, summary)
+	assert.Equal(t, 0, summary.HeldCount)
+	assert.Empty(t, summary.Lots)
+}
+
+func TestCalculateNFTPnL_Window(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cost := 1000.0
+	proceeds := 1500.0
+	earlyCost := 200.0
+	earlyProceeds := 300.0
+
+	transfers := []models.NFTTransfer{
+		// Bought before the window and sold inside it
+		{ContractAddress: "0xpunks", TokenID: "1", Direction: "in", Quantity: 1, ValueUSD: &cost, TransactionHash: "0xbuy", Timestamp: from.AddDate(0, -6, 0)},
+		{ContractAddress: "0xpunks", TokenID: "1", Direction: "out", Quantity: 1, ValueUSD: &proceeds, TransactionHash: "0xsell", Timestamp: from.AddDate(0, 1, 0)},
+		// Bought and sold before the window
+		{ContractAddress: "0xapes", TokenID: "2", Direction: "in", Quantity: 1, ValueUSD: &earlyCost, TransactionHash: "0xearlybuy", Timestamp: from.AddDate(0, -3, 0)},
+		{ContractAddress: "0xapes", TokenID: "2", Direction: "out", Quantity: 1, ValueUSD: &earlyProceeds, TransactionHash: "0xearlysell", Timestamp: from.AddDate(0, -2, 0)},
+		// Bought before the window and still held
+		{ContractAddress: "0xapes", TokenID: "3", Direction: "in", Quantity: 1, ValueUSD: &earlyCost, TransactionHash: "0xhold", Timestamp: from.AddDate(0, -1, 0)},
+	}
+
+	summary := CalculateNFTPnL(transfers, from)
+
+	// The sale is matched against the pre-window purchase rather than a zero cost
+	assert.InDelta(t, 500.0, summary.RealizedPnLUSD, 1e-9)
+	assert.Equal(t, 1, summary.DisposedCount)
+	assert.Equal(t, 1, summary.HeldCount)
+	assert.InDelta(t, 200.0, summary.CostBasisHeldUSD, 1e-9)
+	require.Len(t, summary.Lots, 2)
+	assert.Equal(t, "0xbuy", summary.Lots[0].AcquiredTxHash)
+	assert.Equal(t, "0xhold", summary.Lots[1].AcquiredTxHash)
+}
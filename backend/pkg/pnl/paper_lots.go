@@ -0,0 +1,33 @@
+package pnl
+
+import (
+	"fmt"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// PaperLots turns paper trades into PnL lots for one asset. Yield received is a buy at the
+// price it was credited at: its value is income, and only later moves are gains.
+func PaperLots(trades []*models.PaperTrade, asset string) []models.PnLLot {
+	lots := make([]models.PnLLot, 0, len(trades))
+	for _, trade := range trades {
+		if trade.Asset != asset || trade.Quantity <= 0 {
+			continue
+		}
+		lot := models.PnLLot{
+			ID:              trade.ID,
+			TransactionHash: fmt.Sprintf("paper:%s", trade.ID),
+			Type:            "buy",
+			Quantity:        fmt.Sprintf("%.18f", trade.Quantity),
+			PriceUSD:        fmt.Sprintf("%.10f", trade.PriceUSD),
+			Timestamp:       trade.ExecutedAt,
+			Source:          "manual",
+		}
+		if trade.Side == models.PaperTradeSell {
+			lot.Type = "sell"
+		}
+		lot.RemainingQuantity = lot.Quantity
+		lots = append(lots, lot)
+	}
+	return lots
+}
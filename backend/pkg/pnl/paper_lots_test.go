@@ -0,0 +1,33 @@
+package pnl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaperLots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	trades := []*models.PaperTrade{
+		{ID: uuid.New(), Side: models.PaperTradeBuy, Asset: "ETH", Quantity: 2, PriceUSD: 2000, ExecutedAt: start},
+		{ID: uuid.New(), Side: models.PaperTradeBuy, Asset: "BTC", Quantity: 1, PriceUSD: 40000, ExecutedAt: start},
+		{ID: uuid.New(), Side: models.PaperTradeYield, Asset: "ETH", Quantity: 0.1, PriceUSD: 2500, ExecutedAt: start.Add(time.Hour)},
+		{ID: uuid.New(), Side: models.PaperTradeSell, Asset: "ETH", Quantity: 1, PriceUSD: 3000, ExecutedAt: start.Add(2 * time.Hour)},
+	}
+
+	lots := PaperLots(trades, "ETH")
+	require.Len(t, lots, 3)
+	assert.Equal(t, "buy", lots[0].Type)
+	assert.Equal(t, "buy", lots[1].Type, "yield is acquired at its price")
+	assert.Equal(t, "sell", lots[2].Type)
+
+	calculation, err := NewCalculator(FIFO).CalculatePnL(lots, "3000")
+	require.NoError(t, err)
+	assert.InDelta(t, 1000, parseFloat(t, calculation.RealizedPnLUSD), 1e-6)
+	assert.InDelta(t, 1.1, parseFloat(t, calculation.CurrentQuantity), 1e-12)
+	assert.InDelta(t, 1000+50, parseFloat(t, calculation.UnrealizedPnLUSD), 1e-6)
+}
@@ -2,10 +2,12 @@ package pnl
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -17,6 +19,13 @@ type Repository interface {
 	GetLotsByWalletAndToken(ctx context.Context, walletID uuid.UUID, tokenID uuid.UUID) ([]models.PnLLot, error)
 	UpdateLotRemainingQuantity(ctx context.Context, lotID uuid.UUID, remainingQuantity string) error
 	GetWalletTokens(ctx context.Context, walletID uuid.UUID) ([]uuid.UUID, error)
+	UpsertNFTTransfer(ctx context.Context, transfer *models.NFTTransfer) error
+	GetNFTTransfersByWallet(ctx context.Context, walletID uuid.UUID, to time.Time) ([]models.NFTTransfer, error)
+	GetOpenDerivativePositions(ctx context.Context, walletAddress string) ([]*models.DerivativePosition, error)
+	GetFundingPayments(ctx context.Context, walletAddress string, from, to time.Time) ([]models.FundingPayment, error)
+	GetIncomeCandidates(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]IncomeCandidate, error)
+	GetUnmatchedTransferLots(ctx context.Context, since time.Time) ([]TransferLot, error)
+	CreateInternalTransfer(ctx context.Context, transfer *InternalTransfer) (bool, error)
 }
 
 type repository struct {
@@ -28,11 +37,15 @@ func NewRepository(db *pgxpool.Pool) Repository {
 }
 
 func (r *repository) CreateLot(ctx context.Context, lot *models.PnLLot) error {
+	if lot.Source == "" {
+		lot.Source = models.SourceOnchain
+	}
+
 	query := `
 		INSERT INTO pnl_lots (
 			id, wallet_id, token_id, transaction_hash, chain_id, type,
-			quantity, price_usd, remaining_quantity, block_number, timestamp
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			quantity, price_usd, remaining_quantity, block_number, timestamp, source
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	_, err := r.db.Exec(ctx, query,
@@ -47,6 +60,7 @@ func (r *repository) CreateLot(ctx context.Context, lot *models.PnLLot) error {
 		lot.RemainingQuantity,
 		lot.BlockNumber,
 		lot.Timestamp,
+		lot.Source,
 	)
 
 	return err
@@ -57,7 +71,7 @@ func (r *repository) GetLotsByWallet(ctx context.Context, walletID uuid.UUID, to
 		SELECT 
 			id, wallet_id, token_id, transaction_hash, chain_id, type,
 			quantity, price_usd, remaining_quantity, block_number, timestamp,
-			created_at, updated_at
+			source, internal_transfer_id, cost_basis_usd, created_at, updated_at
 		FROM pnl_lots 
 		WHERE wallet_id = $1 AND token_id = $2 
 		AND timestamp >= $3 AND timestamp <= $4
@@ -78,7 +92,7 @@ func (r *repository) GetLotsByWalletAndToken(ctx context.Context, walletID uuid.
 		SELECT 
 			id, wallet_id, token_id, transaction_hash, chain_id, type,
 			quantity, price_usd, remaining_quantity, block_number, timestamp,
-			created_at, updated_at
+			source, internal_transfer_id, cost_basis_usd, created_at, updated_at
 		FROM pnl_lots 
 		WHERE wallet_id = $1 AND token_id = $2
 		ORDER BY timestamp ASC
@@ -146,6 +160,9 @@ func (r *repository) scanLots(rows pgx.Rows) ([]models.PnLLot, error) {
 			&lot.RemainingQuantity,
 			&lot.BlockNumber,
 			&lot.Timestamp,
+			&lot.Source,
+			&lot.InternalTransferID,
+			&lot.CostBasisUSD,
 			&lot.CreatedAt,
 			&lot.UpdatedAt,
 		)
@@ -156,4 +173,323 @@ func (r *repository) scanLots(rows pgx.Rows) ([]models.PnLLot, error) {
 	}
 
 	return lots, rows.Err()
-}
\ No newline at end of file
+}
+
+func (r *repository) UpsertNFTTransfer(ctx context.Context, transfer *models.NFTTransfer) error {
+	query := `
+		INSERT INTO nft_transfers (
+			wallet_id, chain_id, contract_address, token_id, standard,
+			from_address, to_address, quantity, direction, value_usd,
+			transaction_hash, block_number, block_hash, timestamp
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (wallet_id, transaction_hash, contract_address, token_id, direction) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			value_usd = COALESCE(EXCLUDED.value_usd, nft_transfers.value_usd),
+			block_number = EXCLUDED.block_number,
+			block_hash = COALESCE(EXCLUDED.block_hash, nft_transfers.block_hash),
+			timestamp = EXCLUDED.timestamp
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		transfer.WalletID,
+		transfer.ChainID,
+		transfer.ContractAddress,
+		transfer.TokenID,
+		transfer.Standard,
+		transfer.FromAddress,
+		transfer.ToAddress,
+		transfer.Quantity,
+		transfer.Direction,
+		transfer.ValueUSD,
+		transfer.TransactionHash,
+		transfer.BlockNumber,
+		transfer.BlockHash,
+		transfer.Timestamp,
+	)
+
+	return err
+}
+
+// GetNFTTransfersByWallet returns a wallet's NFT transfers up to to, oldest first. The
+// whole history is loaded so lots acquired before a reporting window can be matched.
+func (r *repository) GetNFTTransfersByWallet(ctx context.Context, walletID uuid.UUID, to time.Time) ([]models.NFTTransfer, error) {
+	query := `
+		SELECT
+			id, wallet_id, chain_id, contract_address, token_id, standard,
+			from_address, to_address, quantity, direction, value_usd,
+			transaction_hash, block_number, timestamp, created_at
+		FROM nft_transfers
+		WHERE wallet_id = $1
+		AND timestamp <= $2
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, walletID, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []models.NFTTransfer
+	for rows.Next() {
+		var t models.NFTTransfer
+		err := rows.Scan(
+			&t.ID,
+			&t.WalletID,
+			&t.ChainID,
+			&t.ContractAddress,
+			&t.TokenID,
+			&t.Standard,
+			&t.FromAddress,
+			&t.ToAddress,
+			&t.Quantity,
+			&t.Direction,
+			&t.ValueUSD,
+			&t.TransactionHash,
+			&t.BlockNumber,
+			&t.Timestamp,
+			&t.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan nft transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, rows.Err()
+}
+
+func (r *repository) GetOpenDerivativePositions(ctx context.Context, walletAddress string) ([]*models.DerivativePosition, error) {
+	query := `
+		SELECT ` + repos.DerivativePositionColumns + `
+		FROM derivative_positions
+		WHERE wallet_address = LOWER($1) AND is_open
+		ORDER BY size_usd DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return repos.ScanDerivativePositions(rows)
+}
+
+func (r *repository) GetFundingPayments(ctx context.Context, walletAddress string, from, to time.Time) ([]models.FundingPayment, error) {
+	query := `
+		SELECT
+			wallet_address, venue, market, amount_usd::float8, funding_rate::float8,
+			position_size::float8, paid_at
+		FROM derivative_funding_payments
+		WHERE wallet_address = LOWER($1)
+		AND paid_at >= $2 AND paid_at <= $3
+		ORDER BY paid_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, walletAddress, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.FundingPayment
+	for rows.Next() {
+		var p models.FundingPayment
+		err := rows.Scan(
+			&p.WalletAddress,
+			&p.Venue,
+			&p.Market,
+			&p.AmountUSD,
+			&p.FundingRate,
+			&p.PositionSize,
+			&p.PaidAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan funding payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+// GetIncomeCandidates returns the wallet's lots acquired by inbound transfers over a
+// period, with the transfer details and the owner's category needed to classify them
+func (r *repository) GetIncomeCandidates(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]IncomeCandidate, error) {
+	query := `
+		SELECT
+			l.id, l.wallet_id, l.token_id, l.transaction_hash, l.chain_id, l.type,
+			l.quantity, l.price_usd, l.remaining_quantity, l.block_number, l.timestamp,
+			l.source, l.internal_transfer_id, l.cost_basis_usd, l.created_at, l.updated_at,
+			tk.symbol, tk.address, w.address, t.from_address, t.to_address, t.metadata,
+			a.category
+		FROM pnl_lots l
+		JOIN transactions t ON t.hash = l.transaction_hash AND t.chain_id = l.chain_id
+		JOIN tokens tk ON tk.id = l.token_id
+		JOIN wallets w ON w.id = l.wallet_id
+		LEFT JOIN transaction_annotations a ON a.transaction_hash = lower(l.transaction_hash) AND a.user_id = w.user_id
+		WHERE l.wallet_id = $1 AND l.type = 'buy' AND t.type = 'receive'
+		AND l.internal_transfer_id IS NULL
+		AND l.timestamp >= $2 AND l.timestamp <= $3
+		ORDER BY l.timestamp ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, walletID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get income candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []IncomeCandidate
+	for rows.Next() {
+		var c IncomeCandidate
+		var metadataJSON []byte
+		err := rows.Scan(
+			&c.Lot.ID,
+			&c.Lot.WalletID,
+			&c.Lot.TokenID,
+			&c.Lot.TransactionHash,
+			&c.Lot.ChainID,
+			&c.Lot.Type,
+			&c.Lot.Quantity,
+			&c.Lot.PriceUSD,
+			&c.Lot.RemainingQuantity,
+			&c.Lot.BlockNumber,
+			&c.Lot.Timestamp,
+			&c.Lot.Source,
+			&c.Lot.InternalTransferID,
+			&c.Lot.CostBasisUSD,
+			&c.Lot.CreatedAt,
+			&c.Lot.UpdatedAt,
+			&c.TokenSymbol,
+			&c.TokenAddress,
+			&c.WalletAddress,
+			&c.FromAddress,
+			&c.ToAddress,
+			&metadataJSON,
+			&c.Override,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan income candidate: %w", err)
+		}
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &c.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal transaction metadata: %w", err)
+			}
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// GetUnmatchedTransferLots returns unpaired lots from plain sends, receives and bridge
+// transactions since a time, for users with more than one wallet. Testnet wallets are
+// left out, so testnet tokens never pair with mainnet ones.
+func (r *repository) GetUnmatchedTransferLots(ctx context.Context, since time.Time) ([]TransferLot, error) {
+	query := `
+		SELECT
+			l.id, l.wallet_id, l.token_id, l.transaction_hash, l.chain_id, l.type,
+			l.quantity, l.price_usd, l.remaining_quantity, l.block_number, l.timestamp,
+			l.source, l.internal_transfer_id, l.cost_basis_usd, l.created_at, l.updated_at,
+			w.user_id, tk.symbol, t.type, lower(COALESCE(t.to_address, ''))
+		FROM pnl_lots l
+		JOIN wallets w ON w.id = l.wallet_id
+		JOIN transactions t ON t.hash = l.transaction_hash AND t.chain_id = l.chain_id
+		JOIN tokens tk ON tk.id = l.token_id
+		WHERE l.internal_transfer_id IS NULL
+		AND l.source = 'onchain'
+		AND l.timestamp >= $1
+		AND ((l.type = 'sell' AND t.type IN ('send', 'bridge')) OR (l.type = 'buy' AND t.type IN ('receive', 'bridge')))
+		AND NOT w.is_testnet
+		AND EXISTS (SELECT 1 FROM wallets ow WHERE ow.user_id = w.user_id AND ow.id <> w.id AND NOT ow.is_testnet)
+		ORDER BY w.user_id, l.timestamp ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unmatched transfer lots: %w", err)
+	}
+	defer rows.Close()
+
+	var lots []TransferLot
+	for rows.Next() {
+		var lot TransferLot
+		err := rows.Scan(
+			&lot.Lot.ID,
+			&lot.Lot.WalletID,
+			&lot.Lot.TokenID,
+			&lot.Lot.TransactionHash,
+			&lot.Lot.ChainID,
+			&lot.Lot.Type,
+			&lot.Lot.Quantity,
+			&lot.Lot.PriceUSD,
+			&lot.Lot.RemainingQuantity,
+			&lot.Lot.BlockNumber,
+			&lot.Lot.Timestamp,
+			&lot.Lot.Source,
+			&lot.Lot.InternalTransferID,
+			&lot.Lot.CostBasisUSD,
+			&lot.Lot.CreatedAt,
+			&lot.Lot.UpdatedAt,
+			&lot.UserID,
+			&lot.TokenSymbol,
+			&lot.TransactionType,
+			&lot.Counterparty,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transfer lot: %w", err)
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, rows.Err()
+}
+
+// CreateInternalTransfer records a pairing and marks both lots with it, carrying the
+// transfer's cost basis into the arriving lot. It returns false, storing nothing, if
+// either lot has been paired since it was read.
+func (r *repository) CreateInternalTransfer(ctx context.Context, transfer *InternalTransfer) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO internal_transfers (user_id, out_lot_id, in_lot_id, kind)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, transfer.UserID, transfer.Out.ID, transfer.In.ID, transfer.Kind).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create internal transfer: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE pnl_lots SET
+			internal_transfer_id = $1,
+			cost_basis_usd = CASE WHEN id = $3 THEN $4::numeric ELSE cost_basis_usd END,
+			updated_at = NOW()
+		WHERE id IN ($2, $3) AND internal_transfer_id IS NULL
+	`, id, transfer.Out.ID, transfer.In.ID, transfer.CostBasisUSD)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark internal transfer lots: %w", err)
+	}
+	if tag.RowsAffected() != 2 {
+		return false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit internal transfer: %w", err)
+	}
+	transfer.Out.InternalTransferID = &id
+	transfer.In.InternalTransferID = &id
+	transfer.In.CostBasisUSD = transfer.CostBasisUSD
+	return true, nil
+}
@@ -3,25 +3,39 @@ package pnl
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/defi-dashboard/backend/internal/models"
 	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/logger"
 	"github.com/google/uuid"
 )
 
 type Service interface {
-	CalculatePnL(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod) (*models.PnLCalculation, error)
-	CalculatePnLByToken(ctx context.Context, walletAddress, tokenAddress string, from, to time.Time, method CalculationMethod) (*models.PnLCalculation, error)
+	CalculatePnL(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod, washSales *WashSaleRule) (*models.PnLCalculation, error)
+	CalculatePnLByToken(ctx context.Context, walletAddress, tokenAddress string, from, to time.Time, method CalculationMethod, washSales *WashSaleRule) (*models.PnLCalculation, error)
 	CreateLotFromTransaction(ctx context.Context, transaction *models.Transaction, tokenID uuid.UUID, quantity, priceUSD string) error
-	GetPnLExportData(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod) ([]models.PnLExportData, error)
+	GetPnLExportData(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod, washSales *WashSaleRule) ([]models.PnLExportData, error)
+	CalculateNFTPnL(ctx context.Context, walletAddress string, from, to time.Time) (*models.NFTPnLSummary, error)
+	SyncNFTTransfers(ctx context.Context, walletID uuid.UUID, transfers []*models.NFTTransfer) (int, error)
+	CalculateIncome(ctx context.Context, walletAddress string, from, to time.Time) (*models.IncomeSummary, error)
+	MatchInternalTransfers(ctx context.Context, since time.Time) (int, error)
+}
+
+// BridgeStatusSource looks up where a bridge transfer arrived, returning an empty hash
+// if the bridge hasn't delivered it or doesn't know it
+type BridgeStatusSource interface {
+	DestinationHash(ctx context.Context, fromChainID, toChainID int, txHash string) (string, error)
 }
 
 type service struct {
-	pnlRepo     Repository
-	walletRepo  repos.WalletRepository
-	tokenRepo   repos.TokenRepository
-	calculator  *Calculator
+	pnlRepo      Repository
+	walletRepo   repos.WalletRepository
+	tokenRepo    repos.TokenRepository
+	calculator   *Calculator
+	bridgeStatus BridgeStatusSource
 }
 
 func NewService(pnlRepo Repository, walletRepo repos.WalletRepository, tokenRepo repos.TokenRepository) Service {
@@ -33,7 +47,15 @@ func NewService(pnlRepo Repository, walletRepo repos.WalletRepository, tokenRepo
 	}
 }
 
-func (s *service) CalculatePnL(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod) (*models.PnLCalculation, error) {
+// NewServiceWithBridgeStatus creates a service that confirms bridge transfers with the
+// bridges' status APIs before falling back to amount and time matching
+func NewServiceWithBridgeStatus(pnlRepo Repository, walletRepo repos.WalletRepository, tokenRepo repos.TokenRepository, bridgeStatus BridgeStatusSource) Service {
+	s := NewService(pnlRepo, walletRepo, tokenRepo).(*service)
+	s.bridgeStatus = bridgeStatus
+	return s
+}
+
+func (s *service) CalculatePnL(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod, washSales *WashSaleRule) (*models.PnLCalculation, error) {
 	// Get wallet ID
 	wallet, err := s.walletRepo.GetByAddress(ctx, walletAddress, 1)
 	if err != nil {
@@ -52,10 +74,212 @@ func (s *service) CalculatePnL(ctx context.Context, walletAddress string, from,
 
 	// For now, calculate for the first token
 	// In a full implementation, this would aggregate across all tokens
-	return s.CalculatePnLByToken(ctx, walletAddress, "", from, to, method)
+	calculation, err := s.CalculatePnLByToken(ctx, walletAddress, "", from, to, method, washSales)
+	if err != nil {
+		return nil, err
+	}
+
+	// NFT lots are tracked separately and reported alongside token PnL
+	nftSummary, err := s.calculateNFTPnLForWallet(ctx, wallet.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if nftSummary.HeldCount > 0 || nftSummary.DisposedCount > 0 {
+		calculation.NFT = nftSummary
+	}
+
+	// Perpetual positions are keyed by address rather than wallet, as they aren't chain-bound
+	derivatives, err := s.calculateDerivativesPnL(ctx, wallet.Address, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if derivatives.OpenPositions > 0 || derivatives.NetFundingUSD != 0 {
+		calculation.Derivatives = derivatives
+	}
+
+	// Income is taxed on receipt, so it is totalled apart from realized gains
+	income, err := s.calculateIncomeForWallet(ctx, wallet.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(income.Events) > 0 {
+		calculation.Income = income
+	}
+
+	return calculation, nil
+}
+
+// CalculateIncome returns a wallet's income over a period, by category
+func (s *service) CalculateIncome(ctx context.Context, walletAddress string, from, to time.Time) (*models.IncomeSummary, error) {
+	wallet, err := s.walletRepo.GetByAddress(ctx, walletAddress, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	return s.calculateIncomeForWallet(ctx, wallet.ID, from, to)
+}
+
+// MatchInternalTransfers pairs transfers between users' own wallets made since a time,
+// within a chain or bridged across chains, so they realize no PnL and the arriving lots
+// keep the cost basis of those they left. It returns how many were paired.
+func (s *service) MatchInternalTransfers(ctx context.Context, since time.Time) (int, error) {
+	lots, err := s.pnlRepo.GetUnmatchedTransferLots(ctx, since)
+	if err != nil {
+		return 0, err
+	}
+
+	byUser := make(map[uuid.UUID][]TransferLot)
+	for _, lot := range lots {
+		byUser[lot.UserID] = append(byUser[lot.UserID], lot)
+	}
+
+	paired := 0
+	for _, userLots := range byUser {
+		transfers := MatchInternalTransfers(userLots)
+
+		matched := make(map[uuid.UUID]bool)
+		for _, transfer := range transfers {
+			matched[transfer.Out.ID], matched[transfer.In.ID] = true, true
+		}
+		var remaining []TransferLot
+		for _, lot := range userLots {
+			if !matched[lot.Lot.ID] {
+				remaining = append(remaining, lot)
+			}
+		}
+		transfers = append(transfers, MatchBridgeTransfers(remaining, s.bridgeDestinations(ctx, remaining))...)
+
+		// Earlier transfers go first so a lot carried on again has its basis by then
+		sort.SliceStable(transfers, func(i, j int) bool {
+			return transfers[i].Out.Timestamp.Before(transfers[j].Out.Timestamp)
+		})
+		for _, transfer := range transfers {
+			transfer := transfer
+			if err := s.carryCostBasis(ctx, &transfer); err != nil {
+				return paired, err
+			}
+			created, err := s.pnlRepo.CreateInternalTransfer(ctx, &transfer)
+			if err != nil {
+				return paired, err
+			}
+			if created {
+				paired++
+			}
+		}
+	}
+	return paired, nil
+}
+
+// carryCostBasis sets the cost basis a transfer carries from the sending wallet's lots
+func (s *service) carryCostBasis(ctx context.Context, transfer *InternalTransfer) error {
+	lots, err := s.pnlRepo.GetLotsByWalletAndToken(ctx, transfer.Out.WalletID, transfer.Out.TokenID)
+	if err != nil {
+		return fmt.Errorf("failed to get sending wallet lots: %w", err)
+	}
+
+	costBasis, err := CarriedCostBasis(lots, transfer.Out, transfer.In)
+	if err != nil {
+		return fmt.Errorf("failed to carry cost basis: %w", err)
+	}
+	transfer.CostBasisUSD = &costBasis
+	return nil
+}
+
+// bridgeDestinations asks the bridges' status APIs where bridge transfers among lots
+// arrived, keyed by lowercase source hash. Lookups that fail are left to amount and time
+// matching.
+func (s *service) bridgeDestinations(ctx context.Context, lots []TransferLot) map[string]string {
+	destinations := make(map[string]string)
+	if s.bridgeStatus == nil {
+		return destinations
+	}
+
+	chains := make(map[int]bool)
+	for _, lot := range lots {
+		if lot.Lot.Type == "buy" {
+			chains[lot.Lot.ChainID] = true
+		}
+	}
+
+	for _, lot := range lots {
+		if !IsBridgeTransfer(lot) {
+			continue
+		}
+		hash := strings.ToLower(lot.Lot.TransactionHash)
+		for chainID := range chains {
+			if chainID == lot.Lot.ChainID {
+				continue
+			}
+			destination, err := s.bridgeStatus.DestinationHash(ctx, lot.Lot.ChainID, chainID, lot.Lot.TransactionHash)
+			if err != nil {
+				logger.Warn("Failed to get bridge transfer status", "error", err, "hash", lot.Lot.TransactionHash, "toChainID", chainID)
+				continue
+			}
+			if destination != "" {
+				destinations[hash] = destination
+				break
+			}
+		}
+	}
+	return destinations
+}
+
+func (s *service) calculateIncomeForWallet(ctx context.Context, walletID uuid.UUID, from, to time.Time) (*models.IncomeSummary, error) {
+	candidates, err := s.pnlRepo.GetIncomeCandidates(ctx, walletID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return CalculateIncome(candidates), nil
+}
+
+// CalculateNFTPnL returns NFT cost basis and realized PnL for a wallet
+func (s *service) CalculateNFTPnL(ctx context.Context, walletAddress string, from, to time.Time) (*models.NFTPnLSummary, error) {
+	wallet, err := s.walletRepo.GetByAddress(ctx, walletAddress, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	return s.calculateNFTPnLForWallet(ctx, wallet.ID, from, to)
+}
+
+// SyncNFTTransfers stores parsed NFT transfers for a wallet and returns how many were written
+func (s *service) SyncNFTTransfers(ctx context.Context, walletID uuid.UUID, transfers []*models.NFTTransfer) (int, error) {
+	stored := 0
+	for _, transfer := range transfers {
+		transfer.WalletID = walletID
+		if err := s.pnlRepo.UpsertNFTTransfer(ctx, transfer); err != nil {
+			return stored, fmt.Errorf("failed to store nft transfer %s: %w", transfer.TransactionHash, err)
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+func (s *service) calculateNFTPnLForWallet(ctx context.Context, walletID uuid.UUID, from, to time.Time) (*models.NFTPnLSummary, error) {
+	transfers, err := s.pnlRepo.GetNFTTransfersByWallet(ctx, walletID, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nft transfers: %w", err)
+	}
+
+	return CalculateNFTPnL(transfers, from), nil
+}
+
+func (s *service) calculateDerivativesPnL(ctx context.Context, walletAddress string, from, to time.Time) (*models.DerivativesPnLSummary, error) {
+	positions, err := s.pnlRepo.GetOpenDerivativePositions(ctx, walletAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get derivative positions: %w", err)
+	}
+
+	payments, err := s.pnlRepo.GetFundingPayments(ctx, walletAddress, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding payments: %w", err)
+	}
+
+	return CalculateDerivativesPnL(positions, payments), nil
 }
 
-func (s *service) CalculatePnLByToken(ctx context.Context, walletAddress, tokenAddress string, from, to time.Time, method CalculationMethod) (*models.PnLCalculation, error) {
+func (s *service) CalculatePnLByToken(ctx context.Context, walletAddress, tokenAddress string, from, to time.Time, method CalculationMethod, washSales *WashSaleRule) (*models.PnLCalculation, error) {
 	// Get wallet
 	wallet, err := s.walletRepo.GetByAddress(ctx, walletAddress, 1)
 	if err != nil {
@@ -92,7 +316,7 @@ func (s *service) CalculatePnLByToken(ctx context.Context, walletAddress, tokenA
 	}
 
 	// Create calculator with specified method
-	calculator := NewCalculator(method)
+	calculator := NewCalculatorWithWashSales(method, washSales)
 
 	// Get current price (assuming it's stored in the token)
 	currentPriceUSD := "0"
@@ -110,6 +334,8 @@ func (s *service) CalculatePnLByToken(ctx context.Context, walletAddress, tokenA
 	calculation.WalletAddress = walletAddress
 	calculation.TokenAddress = token.Address
 	calculation.TokenSymbol = token.Symbol
+	asset := token.Asset().ID
+	calculation.Asset = &asset
 
 	return calculation, nil
 }
@@ -141,7 +367,7 @@ func (s *service) CreateLotFromTransaction(ctx context.Context, transaction *mod
 	return s.pnlRepo.CreateLot(ctx, lot)
 }
 
-func (s *service) GetPnLExportData(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod) ([]models.PnLExportData, error) {
+func (s *service) GetPnLExportData(ctx context.Context, walletAddress string, from, to time.Time, method CalculationMethod, washSales *WashSaleRule) ([]models.PnLExportData, error) {
 	// Get wallet
 	wallet, err := s.walletRepo.GetByAddress(ctx, walletAddress, 1)
 	if err != nil {
@@ -170,7 +396,7 @@ func (s *service) GetPnLExportData(ctx context.Context, walletAddress string, fr
 		}
 
 		// Create calculator with specified method
-		calculator := NewCalculator(method)
+		calculator := NewCalculatorWithWashSales(method, washSales)
 		
 		// Get current price
 		currentPriceUSD := "0"
@@ -186,6 +412,10 @@ func (s *service) GetPnLExportData(ctx context.Context, walletAddress string, fr
 
 		// Convert lots to export data
 		for _, lot := range calculation.Lots {
+			adjustment := ""
+			if lot.WashSaleAdjustmentUSD != nil {
+				adjustment = *lot.WashSaleAdjustmentUSD
+			}
 			exportData = append(exportData, models.PnLExportData{
 				WalletAddress:     walletAddress,
 				TokenSymbol:       token.Symbol,
@@ -198,6 +428,8 @@ func (s *service) GetPnLExportData(ctx context.Context, walletAddress string, fr
 				RealizedPnLUSD:    "0", // This would need to be calculated per lot
 				Timestamp:         lot.Timestamp,
 				BlockNumber:       lot.BlockNumber,
+				WashSale:          lot.WashSale,
+				WashSaleAdjustmentUSD: adjustment,
 			})
 		}
 	}
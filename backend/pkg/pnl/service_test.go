@@ -42,38 +42,98 @@ func (m *MockPnLRepository) GetWalletTokens(ctx context.Context, walletID uuid.U
 	return args.Get(0).([]uuid.UUID), args.Error(1)
 }
 
+func (m *MockPnLRepository) UpsertNFTTransfer(ctx context.Context, transfer *models.NFTTransfer) error {
+	args := m.Called(ctx, transfer)
+	return args.Error(0)
+}
+
+func (m *MockPnLRepository) GetNFTTransfersByWallet(ctx context.Context, walletID uuid.UUID, to time.Time) ([]models.NFTTransfer, error) {
+	args := m.Called(ctx, walletID, to)
+	return args.Get(0).([]models.NFTTransfer), args.Error(1)
+}
+
+func (m *MockPnLRepository) GetOpenDerivativePositions(ctx context.Context, walletAddress string) ([]*models.DerivativePosition, error) {
+	args := m.Called(ctx, walletAddress)
+	return args.Get(0).([]*models.DerivativePosition), args.Error(1)
+}
+
+func (m *MockPnLRepository) GetFundingPayments(ctx context.Context, walletAddress string, from, to time.Time) ([]models.FundingPayment, error) {
+	args := m.Called(ctx, walletAddress, from, to)
+	return args.Get(0).([]models.FundingPayment), args.Error(1)
+}
+
+func (m *MockPnLRepository) GetIncomeCandidates(ctx context.Context, walletID uuid.UUID, from, to time.Time) ([]IncomeCandidate, error) {
+	args := m.Called(ctx, walletID, from, to)
+	return args.Get(0).([]IncomeCandidate), args.Error(1)
+}
+
+func (m *MockPnLRepository) GetUnmatchedTransferLots(ctx context.Context, since time.Time) ([]TransferLot, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]TransferLot), args.Error(1)
+}
+
+func (m *MockPnLRepository) CreateInternalTransfer(ctx context.Context, transfer *InternalTransfer) (bool, error) {
+	args := m.Called(ctx, transfer)
+	return args.Bool(0), args.Error(1)
+}
+
 type MockWalletRepository struct {
 	mock.Mock
 }
 
-func (m *MockWalletRepository) GetByAddress(ctx context.Context, address string) (*models.Wallet, error) {
-	args := m.Called(ctx, address)
+func (m *MockWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Wallet, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletRepository) GetByAddress(ctx context.Context, address string, chainID int) (*models.Wallet, error) {
+	args := m.Called(ctx, address, chainID)
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
+func (m *MockWalletRepository) GetAllByAddresses(ctx context.Context, addresses []string, chainID int) ([]*models.Wallet, error) {
+	args := m.Called(ctx, addresses, chainID)
+	return args.Get(0).([]*models.Wallet), args.Error(1)
+}
+
 func (m *MockWalletRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-func (m *MockWalletRepository) Create(ctx context.Context, wallet *models.Wallet) error {
-	args := m.Called(ctx, wallet)
-	return args.Error(0)
+func (m *MockWalletRepository) Create(ctx context.Context, userID uuid.UUID, address string, chainID int, label *string, isPrimary bool) (*models.Wallet, error) {
+	args := m.Called(ctx, userID, address, chainID, label, isPrimary)
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletRepository) Update(ctx context.Context, id, userID uuid.UUID, label *string) (*models.Wallet, error) {
+	args := m.Called(ctx, id, userID, label)
+	return args.Get(0).(*models.Wallet), args.Error(1)
 }
 
-func (m *MockWalletRepository) Update(ctx context.Context, wallet *models.Wallet) error {
-	args := m.Called(ctx, wallet)
+func (m *MockWalletRepository) SetVisibility(ctx context.Context, id, userID uuid.UUID, visibility string) (*models.Wallet, error) {
+	args := m.Called(ctx, id, userID, visibility)
+	return args.Get(0).(*models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletRepository) GetUndetectedAccounts(ctx context.Context, limit int) ([]*models.Wallet, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]*models.Wallet), args.Error(1)
+}
+
+func (m *MockWalletRepository) SetAccountType(ctx context.Context, id uuid.UUID, accountType string) error {
+	args := m.Called(ctx, id, accountType)
 	return args.Error(0)
 }
 
-func (m *MockWalletRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
+func (m *MockWalletRepository) SetPrimary(ctx context.Context, userID, walletID uuid.UUID) error {
+	args := m.Called(ctx, userID, walletID)
 	return args.Error(0)
 }
 
-func (m *MockWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.Wallet, error) {
-	args := m.Called(ctx, userID)
-	return args.Get(0).([]models.Wallet), args.Error(1)
+func (m *MockWalletRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
 }
 
 type MockTokenRepository struct {
@@ -90,28 +150,33 @@ func (m *MockTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 	return args.Get(0).(*models.Token), args.Error(1)
 }
 
-func (m *MockTokenRepository) Create(ctx context.Context, token *models.Token) error {
-	args := m.Called(ctx, token)
-	return args.Error(0)
+func (m *MockTokenRepository) GetByChainID(ctx context.Context, chainID int, limit, offset int) ([]*models.Token, error) {
+	args := m.Called(ctx, chainID, limit, offset)
+	return args.Get(0).([]*models.Token), args.Error(1)
+}
+
+func (m *MockTokenRepository) Search(ctx context.Context, query string, chainID *int) ([]*models.Token, error) {
+	args := m.Called(ctx, query, chainID)
+	return args.Get(0).([]*models.Token), args.Error(1)
 }
 
-func (m *MockTokenRepository) Update(ctx context.Context, token *models.Token) error {
+func (m *MockTokenRepository) Create(ctx context.Context, token *models.Token) (*models.Token, error) {
 	args := m.Called(ctx, token)
-	return args.Error(0)
+	return args.Get(0).(*models.Token), args.Error(1)
 }
 
-func (m *MockTokenRepository) GetBySymbol(ctx context.Context, symbol string, chainID int) (*models.Token, error) {
-	args := m.Called(ctx, symbol, chainID)
+func (m *MockTokenRepository) UpdatePrice(ctx context.Context, address string, chainID int, priceUSD, priceChange24h, marketCap float64) (*models.Token, error) {
+	args := m.Called(ctx, address, chainID, priceUSD, priceChange24h, marketCap)
 	return args.Get(0).(*models.Token), args.Error(1)
 }
 
-func (m *MockTokenRepository) List(ctx context.Context, chainID int, limit, offset int) ([]models.Token, error) {
-	args := m.Called(ctx, chainID, limit, offset)
-	return args.Get(0).([]models.Token), args.Error(1)
+func (m *MockTokenRepository) GetTokensByAddresses(ctx context.Context, chainID int, addresses []string) (map[string]*models.Token, error) {
+	args := m.Called(ctx, chainID, addresses)
+	return args.Get(0).(map[string]*models.Token), args.Error(1)
 }
 
-func (m *MockTokenRepository) UpdatePrices(ctx context.Context, prices map[string]float64) error {
-	args := m.Called(ctx, prices)
+func (m *MockTokenRepository) UpsertTokens(ctx context.Context, tokens []*models.Token) error {
+	args := m.Called(ctx, tokens)
 	return args.Error(0)
 }
 
@@ -176,12 +241,12 @@ func TestService_CalculatePnLByToken(t *testing.T) {
 	to := time.Now()
 
 	// Setup mock expectations
-	mockWalletRepo.On("GetByAddress", ctx, walletAddress).Return(wallet, nil)
+	mockWalletRepo.On("GetByAddress", ctx, walletAddress, 1).Return(wallet, nil)
 	mockTokenRepo.On("GetByAddress", ctx, tokenAddress, wallet.ChainID).Return(token, nil)
 	mockPnLRepo.On("GetLotsByWallet", ctx, walletID, tokenID, from, to).Return(lots, nil)
 
 	// Execute test
-	result, err := service.CalculatePnLByToken(ctx, walletAddress, tokenAddress, from, to, FIFO)
+	result, err := service.CalculatePnLByToken(ctx, walletAddress, tokenAddress, from, to, FIFO, nil)
 
 	// Assertions
 	require.NoError(t, err)
@@ -233,7 +298,7 @@ func TestService_CreateLotFromTransaction(t *testing.T) {
 	}
 
 	// Setup mock expectations
-	mockWalletRepo.On("GetByAddress", ctx, transaction.FromAddress).Return(wallet, nil)
+	mockWalletRepo.On("GetByAddress", ctx, transaction.FromAddress, 1).Return(wallet, nil)
 	mockPnLRepo.On("CreateLot", ctx, mock.AnythingOfType("*models.PnLLot")).Return(nil)
 
 	// Execute test
@@ -273,31 +338,34 @@ func TestService_DetermineLotType(t *testing.T) {
 func TestService_EdgeCases(t *testing.T) {
 	ctx := context.Background()
 	
-	// Setup mocks
-	mockPnLRepo := new(MockPnLRepository)
-	mockWalletRepo := new(MockWalletRepository)
-	mockTokenRepo := new(MockTokenRepository)
-	
-	service := &service{
-		pnlRepo:    mockPnLRepo,
-		walletRepo: mockWalletRepo,
-		tokenRepo:  mockTokenRepo,
-		calculator: NewCalculator(FIFO),
+	// Setup mocks; each case gets its own, as they answer differently for the same wallet
+	newService := func() (*service, *MockPnLRepository, *MockWalletRepository, *MockTokenRepository) {
+		mockPnLRepo := new(MockPnLRepository)
+		mockWalletRepo := new(MockWalletRepository)
+		mockTokenRepo := new(MockTokenRepository)
+		return &service{
+			pnlRepo:    mockPnLRepo,
+			walletRepo: mockWalletRepo,
+			tokenRepo:  mockTokenRepo,
+			calculator: NewCalculator(FIFO),
+		}, mockPnLRepo, mockWalletRepo, mockTokenRepo
 	}
 
 	t.Run("Wallet not found", func(t *testing.T) {
+		service, _, mockWalletRepo, _ := newService()
 		walletAddress := "0x1234567890123456789012345678901234567890"
 		from := time.Now().Add(-time.Hour * 48)
 		to := time.Now()
 
-		mockWalletRepo.On("GetByAddress", ctx, walletAddress).Return((*models.Wallet)(nil), assert.AnError)
+		mockWalletRepo.On("GetByAddress", ctx, walletAddress, 1).Return((*models.Wallet)(nil), assert.AnError)
 
-		_, err := service.CalculatePnLByToken(ctx, walletAddress, "", from, to, FIFO)
+		_, err := service.CalculatePnLByToken(ctx, walletAddress, "", from, to, FIFO, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to get wallet")
 	})
 
 	t.Run("No lots found", func(t *testing.T) {
+		service, mockPnLRepo, mockWalletRepo, mockTokenRepo := newService()
 		walletID := uuid.New()
 		tokenID := uuid.New()
 		walletAddress := "0x1234567890123456789012345678901234567890"
@@ -318,16 +386,17 @@ func TestService_EdgeCases(t *testing.T) {
 		from := time.Now().Add(-time.Hour * 48)
 		to := time.Now()
 
-		mockWalletRepo.On("GetByAddress", ctx, walletAddress).Return(wallet, nil)
+		mockWalletRepo.On("GetByAddress", ctx, walletAddress, 1).Return(wallet, nil)
 		mockTokenRepo.On("GetByAddress", ctx, tokenAddress, wallet.ChainID).Return(token, nil)
 		mockPnLRepo.On("GetLotsByWallet", ctx, walletID, tokenID, from, to).Return([]models.PnLLot{}, nil)
 
-		_, err := service.CalculatePnLByToken(ctx, walletAddress, tokenAddress, from, to, FIFO)
+		_, err := service.CalculatePnLByToken(ctx, walletAddress, tokenAddress, from, to, FIFO, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "no lots found")
 	})
 
 	t.Run("Token not found", func(t *testing.T) {
+		service, _, mockWalletRepo, mockTokenRepo := newService()
 		walletID := uuid.New()
 		walletAddress := "0x1234567890123456789012345678901234567890"
 		tokenAddress := "0x0987654321098765432109876543210987654321"
@@ -341,10 +410,10 @@ func TestService_EdgeCases(t *testing.T) {
 		from := time.Now().Add(-time.Hour * 48)
 		to := time.Now()
 
-		mockWalletRepo.On("GetByAddress", ctx, walletAddress).Return(wallet, nil)
+		mockWalletRepo.On("GetByAddress", ctx, walletAddress, 1).Return(wallet, nil)
 		mockTokenRepo.On("GetByAddress", ctx, tokenAddress, wallet.ChainID).Return((*models.Token)(nil), assert.AnError)
 
-		_, err := service.CalculatePnLByToken(ctx, walletAddress, tokenAddress, from, to, FIFO)
+		_, err := service.CalculatePnLByToken(ctx, walletAddress, tokenAddress, from, to, FIFO, nil)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to get token")
 	})
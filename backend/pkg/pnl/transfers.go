@@ -0,0 +1,283 @@
+package pnl
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// InternalTransferWindow is how long after leaving one wallet a transfer may arrive
+	// in another to be paired with it
+	InternalTransferWindow = 6 * time.Hour
+	// InternalTransferTolerance is the share of a transfer that may be lost to fees on
+	// the way, e.g. when moving through an exchange
+	InternalTransferTolerance = 0.02
+	// BridgeTransferWindow is how long after leaving one chain a bridged transfer may
+	// arrive on another to be paired with it by amount and time alone
+	BridgeTransferWindow = 24 * time.Hour
+	// BridgeTransferTolerance is the share of a bridged transfer that may be lost to
+	// bridge and relayer fees
+	BridgeTransferTolerance = 0.05
+)
+
+// Kinds of internal transfer
+const (
+	InternalTransferKindWallet = "wallet"
+	InternalTransferKindBridge = "bridge"
+)
+
+// bridgeContracts are bridge and bridge aggregator entrypoints; a send to one of them is
+// a bridge transfer even when the transaction isn't typed as one
+var bridgeContracts = map[string]string{
+	"0x1231deb6f5749ef6ce6943a275a1d3e7486f4eae": "LI.FI",
+	"0x3a23f943181408eac424116af7b7790c94cb97a5": "Socket",
+	"0x5c7bcd6e7de5423a257d81b442095a1a6ced35c5": "Across",
+	"0x8731d54e9d02c286767d56ac03e8037c07e01e98": "Stargate",
+	"0xbd3fa81b58ba92a82136038b25adec7066af3155": "Circle CCTP",
+	"0x72ce9c846789fdb6fc1f34ac4ad25dd9ef7031ef": "Arbitrum Gateway",
+	"0x99c9fc46f92e8a1c0dec1b1747d010903e884be1": "Optimism Bridge",
+	"0x3154cf16ccdb4c6d922629664174b904d80f2c35": "Base Bridge",
+	"0xa0c68c638235ee32657e8f720a23cec1bfc77c77": "Polygon PoS Bridge",
+}
+
+// bridgedAssets maps symbols of bridged token variants to the asset they represent
+var bridgedAssets = map[string]string{
+	"USDC.E": "USDC",
+	"USDBC":  "USDC",
+	"USDT.E": "USDT",
+	"WETH":   "ETH",
+	"WETH.E": "ETH",
+}
+
+// TransferLot is an unpaired lot created by a plain send, receive or bridge transaction
+// of a user's wallet
+type TransferLot struct {
+	Lot             models.PnLLot
+	UserID          uuid.UUID
+	TokenSymbol     string
+	TransactionType string
+	Counterparty    string // lowercase to_address of the transaction
+}
+
+// InternalTransfer pairs a lot sent from one of a user's wallets with the lot it arrived as
+type InternalTransfer struct {
+	UserID uuid.UUID
+	Kind   string
+	Out    models.PnLLot
+	In     models.PnLLot
+	// CostBasisUSD is the per-unit cost basis carried into In
+	CostBasisUSD *string
+}
+
+// IsBridgeTransfer reports whether a lot left its chain through a bridge
+func IsBridgeTransfer(lot TransferLot) bool {
+	if lot.Lot.Type != "sell" {
+		return false
+	}
+	_, known := bridgeContracts[strings.ToLower(lot.Counterparty)]
+	return lot.TransactionType == "bridge" || known
+}
+
+// bridgedAsset returns the asset a token symbol stands for across chains
+func bridgedAsset(symbol string) string {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if asset, ok := bridgedAssets[symbol]; ok {
+		return asset
+	}
+	return symbol
+}
+
+// MatchInternalTransfers pairs sells with buys of the same token in another wallet of
+// the same user. Both sides of an on-chain transfer between the wallets share a hash and
+// pair first; otherwise a buy pairs if it arrived within InternalTransferWindow of the
+// sell, for no more than was sent and at most InternalTransferTolerance less, the
+// earliest such arrival winning. Each lot is paired at most once.
+func MatchInternalTransfers(lots []TransferLot) []InternalTransfer {
+	m := newTransferMatcher(lots, func(sell, buy TransferLot) bool {
+		return sell.UserID == buy.UserID && sell.Lot.TokenID == buy.Lot.TokenID && sell.Lot.WalletID != buy.Lot.WalletID
+	})
+
+	m.match(InternalTransferKindWallet, func(out, in models.PnLLot) bool { return out.TransactionHash == in.TransactionHash })
+	m.match(InternalTransferKindWallet, func(out, in models.PnLLot) bool {
+		return arrivedWithin(out, in, InternalTransferWindow, InternalTransferTolerance)
+	})
+	return m.matches
+}
+
+// MatchBridgeTransfers pairs bridge transfers leaving one chain with their arrival in a
+// wallet of the same user on another, matching tokens by the asset they represent. A
+// destination hash reported by a bridge's status API, keyed by lowercase source hash,
+// pairs first; otherwise a buy pairs if it arrived within BridgeTransferWindow for no
+// more than was sent and at most BridgeTransferTolerance less. Lots already paired by
+// MatchInternalTransfers should be left out.
+func MatchBridgeTransfers(lots []TransferLot, destinations map[string]string) []InternalTransfer {
+	m := newTransferMatcher(lots, func(sell, buy TransferLot) bool {
+		return sell.UserID == buy.UserID && sell.Lot.ChainID != buy.Lot.ChainID &&
+			IsBridgeTransfer(sell) && bridgedAsset(sell.TokenSymbol) == bridgedAsset(buy.TokenSymbol)
+	})
+
+	m.match(InternalTransferKindBridge, func(out, in models.PnLLot) bool {
+		destination, ok := destinations[strings.ToLower(out.TransactionHash)]
+		return ok && strings.EqualFold(destination, in.TransactionHash)
+	})
+	m.match(InternalTransferKindBridge, func(out, in models.PnLLot) bool {
+		return arrivedWithin(out, in, BridgeTransferWindow, BridgeTransferTolerance)
+	})
+	return m.matches
+}
+
+// transferMatcher pairs sells with buys over successive passes, each lot at most once
+// and sells in time order taking the earliest buy that fits
+type transferMatcher struct {
+	sells, buys       []TransferLot
+	sellUsed, buyUsed []bool
+	pairs             func(sell, buy TransferLot) bool
+	matches           []InternalTransfer
+}
+
+func newTransferMatcher(lots []TransferLot, pairs func(sell, buy TransferLot) bool) *transferMatcher {
+	m := &transferMatcher{pairs: pairs}
+	for _, lot := range lots {
+		switch lot.Lot.Type {
+		case "sell":
+			m.sells = append(m.sells, lot)
+		case "buy":
+			m.buys = append(m.buys, lot)
+		}
+	}
+	sort.SliceStable(m.sells, func(i, j int) bool {
+		return m.sells[i].Lot.Timestamp.Before(m.sells[j].Lot.Timestamp)
+	})
+	sort.SliceStable(m.buys, func(i, j int) bool {
+		return m.buys[i].Lot.Timestamp.Before(m.buys[j].Lot.Timestamp)
+	})
+	m.sellUsed = make([]bool, len(m.sells))
+	m.buyUsed = make([]bool, len(m.buys))
+	return m
+}
+
+func (m *transferMatcher) match(kind string, arrived func(out, in models.PnLLot) bool) {
+	for i, sell := range m.sells {
+		if m.sellUsed[i] {
+			continue
+		}
+		for j, buy := range m.buys {
+			if m.buyUsed[j] || !m.pairs(sell, buy) || !arrived(sell.Lot, buy.Lot) {
+				continue
+			}
+			m.sellUsed[i], m.buyUsed[j] = true, true
+			m.matches = append(m.matches, InternalTransfer{UserID: sell.UserID, Kind: kind, Out: sell.Lot, In: buy.Lot})
+			break
+		}
+	}
+}
+
+// arrivedWithin reports whether in could be out arriving elsewhere within a window, less
+// at most a share of tolerance
+func arrivedWithin(out, in models.PnLLot, window time.Duration, tolerance float64) bool {
+	if in.Timestamp.Before(out.Timestamp) || in.Timestamp.Sub(out.Timestamp) > window {
+		return false
+	}
+
+	sent, ok := new(big.Float).SetString(out.Quantity)
+	if !ok || sent.Sign() <= 0 {
+		return false
+	}
+	received, ok := new(big.Float).SetString(in.Quantity)
+	if !ok || received.Sign() <= 0 || received.Cmp(sent) > 0 {
+		return false
+	}
+	minimum := new(big.Float).Mul(sent, big.NewFloat(1-tolerance))
+	return received.Cmp(minimum) >= 0
+}
+
+// CarriedCostBasis returns the per-unit cost basis a transfer carries into the lot it
+// arrived as: the cost of the sending wallet's lots it consumed, taken FIFO, spread over
+// the quantity received. Any quantity sent beyond the lots held is costed at the
+// transfer's own price. lots are all of the sending wallet's lots of the token.
+func CarriedCostBasis(lots []models.PnLLot, out, in models.PnLLot) (string, error) {
+	ordered := make([]models.PnLLot, len(lots))
+	copy(ordered, lots)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].Timestamp.Equal(ordered[j].Timestamp) {
+			return ordered[i].Timestamp.Before(ordered[j].Timestamp)
+		}
+		// Acquisitions in the same block come before disposals
+		return ordered[i].Type == "buy" && ordered[j].Type != "buy"
+	})
+
+	parse := func(s string) (*big.Float, error) {
+		f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+		return f, err
+	}
+
+	type held struct {
+		remaining *big.Float
+		price     *big.Float
+	}
+	var holdings []held
+	for _, lot := range ordered {
+		quantity, err := parse(lot.Quantity)
+		if err != nil {
+			return "", fmt.Errorf("invalid quantity on lot %s: %w", lot.ID, err)
+		}
+
+		if lot.Type == "buy" {
+			price, err := parse(lotCost(lot))
+			if err != nil {
+				return "", fmt.Errorf("invalid price on lot %s: %w", lot.ID, err)
+			}
+			holdings = append(holdings, held{remaining: quantity, price: price})
+			continue
+		}
+
+		cost := new(big.Float)
+		for i := range holdings {
+			if quantity.Sign() <= 0 {
+				break
+			}
+			if holdings[i].remaining.Sign() <= 0 {
+				continue
+			}
+			matched := new(big.Float).Set(quantity)
+			if holdings[i].remaining.Cmp(matched) < 0 {
+				matched.Set(holdings[i].remaining)
+			}
+			holdings[i].remaining.Sub(holdings[i].remaining, matched)
+			quantity.Sub(quantity, matched)
+			cost.Add(cost, new(big.Float).Mul(matched, holdings[i].price))
+		}
+
+		if lot.ID != out.ID {
+			continue
+		}
+		if quantity.Sign() > 0 {
+			price, err := parse(out.PriceUSD)
+			if err != nil {
+				return "", fmt.Errorf("invalid price on lot %s: %w", out.ID, err)
+			}
+			cost.Add(cost, new(big.Float).Mul(quantity, price))
+		}
+		received, err := parse(in.Quantity)
+		if err != nil || received.Sign() <= 0 {
+			return "", fmt.Errorf("invalid quantity on lot %s", in.ID)
+		}
+		return new(big.Float).Quo(cost, received).Text('f', 10), nil
+	}
+
+	return "", fmt.Errorf("lot %s not found among the sending wallet's lots", out.ID)
+}
+
+// lotCost returns the per-unit cost of a buy lot, preferring a carried cost basis
+func lotCost(lot models.PnLLot) string {
+	if lot.CostBasisUSD != nil {
+		return *lot.CostBasisUSD
+	}
+	return lot.PriceUSD
+}
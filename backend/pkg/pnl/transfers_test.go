@@ -0,0 +1,152 @@
+package pnl
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchInternalTransfers(t *testing.T) {
+	userID, otherUser := uuid.New(), uuid.New()
+	walletA, walletB := uuid.New(), uuid.New()
+	token := uuid.New()
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	lot := func(user, wallet uuid.UUID, lotType, hash, quantity string, ts time.Time) TransferLot {
+		return TransferLot{
+			UserID: user,
+			Lot: models.PnLLot{
+				ID:              uuid.New(),
+				WalletID:        wallet,
+				TokenID:         token,
+				TransactionHash: hash,
+				Type:            lotType,
+				Quantity:        quantity,
+				Timestamp:       ts,
+			},
+		}
+	}
+
+	sameHashOut := lot(userID, walletA, "sell", "0x1", "10", at)
+	sameHashIn := lot(userID, walletB, "buy", "0x1", "10", at)
+	// Through an exchange: arrives later, less a withdrawal fee
+	viaExchangeOut := lot(userID, walletA, "sell", "0x2", "5", at.Add(time.Hour))
+	viaExchangeIn := lot(userID, walletB, "buy", "0x3", "4.95", at.Add(3*time.Hour))
+	// Arrives too late, for too little, or for another user
+	late := lot(userID, walletB, "buy", "0x4", "5", at.Add(time.Hour+InternalTransferWindow+time.Minute))
+	short := lot(userID, walletB, "buy", "0x5", "4", at.Add(2*time.Hour))
+	foreign := lot(otherUser, walletB, "buy", "0x6", "5", at.Add(2*time.Hour))
+	// A receive into the sending wallet never pairs
+	sameWallet := lot(userID, walletA, "buy", "0x7", "5", at.Add(90*time.Minute))
+
+	matches := MatchInternalTransfers([]TransferLot{
+		late, short, foreign, sameWallet, viaExchangeIn, viaExchangeOut, sameHashIn, sameHashOut,
+	})
+	require.Len(t, matches, 2)
+	assert.Equal(t, sameHashOut.Lot.ID, matches[0].Out.ID)
+	assert.Equal(t, sameHashIn.Lot.ID, matches[0].In.ID)
+	assert.Equal(t, viaExchangeOut.Lot.ID, matches[1].Out.ID)
+	assert.Equal(t, viaExchangeIn.Lot.ID, matches[1].In.ID)
+	assert.Equal(t, userID, matches[1].UserID)
+}
+
+func TestCalculator_InternalTransferRealizesNothing(t *testing.T) {
+	transferID := uuid.New()
+	lots := []models.PnLLot{
+		{ID: uuid.New(), Type: "buy", Quantity: "10", PriceUSD: "100", RemainingQuantity: "10", Timestamp: time.Now().Add(-48 * time.Hour)},
+		{ID: uuid.New(), Type: "sell", Quantity: "4", PriceUSD: "150", RemainingQuantity: "4", Timestamp: time.Now().Add(-24 * time.Hour), InternalTransferID: &transferID},
+		{ID: uuid.New(), Type: "sell", Quantity: "2", PriceUSD: "150", RemainingQuantity: "2", Timestamp: time.Now().Add(-12 * time.Hour)},
+	}
+
+	calculation, err := NewCalculator(FIFO).CalculatePnL(lots, "150")
+	require.NoError(t, err)
+	assert.Equal(t, "100", calculation.RealizedPnLUSD, "only the real sale realizes PnL")
+	assert.Equal(t, "4", calculation.CurrentQuantity, "the transferred tokens have left the wallet")
+}
+
+func TestMatchBridgeTransfers(t *testing.T) {
+	userID := uuid.New()
+	mainnet, arbitrum := uuid.New(), uuid.New()
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	lot := func(wallet uuid.UUID, chainID int, lotType, symbol, hash, quantity string, ts time.Time) TransferLot {
+		return TransferLot{
+			UserID:          userID,
+			TokenSymbol:     symbol,
+			TransactionType: "send",
+			Lot: models.PnLLot{
+				ID:              uuid.New(),
+				WalletID:        wallet,
+				TokenID:         uuid.New(),
+				TransactionHash: hash,
+				ChainID:         chainID,
+				Type:            lotType,
+				Quantity:        quantity,
+				Timestamp:       ts,
+			},
+		}
+	}
+
+	// Sent to the Across spoke pool, arriving as bridged USDC a few minutes later
+	acrossOut := lot(mainnet, 1, "sell", "USDC", "0xa1", "1000", at)
+	acrossOut.Counterparty = "0x5c7bcd6e7de5423a257d81b442095a1a6ced35c5"
+	acrossIn := lot(arbitrum, 42161, "buy", "USDC.e", "0xa2", "998.5", at.Add(5*time.Minute))
+	// Confirmed by a status API despite arriving after the window
+	slowOut := lot(mainnet, 1, "sell", "ETH", "0xB1", "2", at)
+	slowOut.TransactionType = "bridge"
+	slowIn := lot(arbitrum, 42161, "buy", "ETH", "0xb2", "2", at.Add(7*24*time.Hour))
+	// A plain send to someone else is no bridge, and another asset never pairs
+	plainOut := lot(mainnet, 1, "sell", "DAI", "0xc1", "50", at)
+	plainIn := lot(arbitrum, 42161, "buy", "DAI", "0xc2", "50", at.Add(time.Minute))
+	otherAsset := lot(arbitrum, 42161, "buy", "USDT", "0xd2", "1000", at.Add(time.Minute))
+
+	matches := MatchBridgeTransfers(
+		[]TransferLot{otherAsset, plainIn, plainOut, slowIn, slowOut, acrossIn, acrossOut},
+		map[string]string{"0xb1": "0xB2"},
+	)
+	require.Len(t, matches, 2)
+	assert.Equal(t, slowOut.Lot.ID, matches[0].Out.ID)
+	assert.Equal(t, slowIn.Lot.ID, matches[0].In.ID)
+	assert.Equal(t, acrossOut.Lot.ID, matches[1].Out.ID)
+	assert.Equal(t, acrossIn.Lot.ID, matches[1].In.ID)
+	assert.Equal(t, InternalTransferKindBridge, matches[1].Kind)
+}
+
+func TestCarriedCostBasis(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	carried := "0.99"
+	lots := []models.PnLLot{
+		{ID: uuid.New(), Type: "buy", Quantity: "600", PriceUSD: "1.00", Timestamp: at},
+		{ID: uuid.New(), Type: "buy", Quantity: "600", PriceUSD: "1.00", CostBasisUSD: &carried, Timestamp: at.Add(time.Hour)},
+		{ID: uuid.New(), Type: "sell", Quantity: "200", PriceUSD: "1.00", Timestamp: at.Add(2 * time.Hour)},
+	}
+	out := models.PnLLot{ID: uuid.New(), Type: "sell", Quantity: "1000", PriceUSD: "1.00", Timestamp: at.Add(3 * time.Hour)}
+	in := models.PnLLot{ID: uuid.New(), Type: "buy", Quantity: "990", PriceUSD: "1.00"}
+
+	// 400 at 1.00 and 600 at the carried 0.99, with the bridge fee folded into the basis
+	costBasis, err := CarriedCostBasis(append(lots, out), out, in)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0040404040", costBasis)
+
+	calculation, err := NewCalculator(FIFO).CalculatePnL([]models.PnLLot{
+		{ID: uuid.New(), Type: "buy", Quantity: "990", PriceUSD: "1.00", CostBasisUSD: &costBasis, RemainingQuantity: "990", Timestamp: at},
+	}, "1.00")
+	require.NoError(t, err)
+	totalCostBasis, err := strconv.ParseFloat(calculation.TotalCostBasisUSD, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, 994, totalCostBasis, 0.001, "the carried basis replaces the market price")
+
+	// Quantity sent beyond the lots held is costed at the transfer's price
+	oversized := models.PnLLot{ID: uuid.New(), Type: "sell", Quantity: "1100", PriceUSD: "2.00", Timestamp: at.Add(3 * time.Hour)}
+	costBasis, err = CarriedCostBasis(append(lots, oversized), oversized, models.PnLLot{Quantity: "1100"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.0854545455", costBasis)
+
+	_, err = CarriedCostBasis(lots, out, in)
+	assert.Error(t, err, "out must be among the lots")
+}
@@ -0,0 +1,173 @@
+package pnl
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+)
+
+// WashSaleRule is a jurisdiction's rule against claiming a loss on a token re-purchased
+// around its sale
+type WashSaleRule struct {
+	Jurisdiction string
+	// Window is how long after a loss, and before it when LookBack is set, a purchase of
+	// the same token counts as its replacement
+	Window   time.Duration
+	LookBack bool
+	// DeferLoss disallows the replaced share of a loss and adds it to the replacement's
+	// cost basis; otherwise wash sales are only flagged
+	DeferLoss bool
+}
+
+// washSaleRules are the supported jurisdiction profiles
+var washSaleRules = map[string]WashSaleRule{
+	// Wash sales: replacements within 30 days either side, loss added to their basis
+	"us": {Jurisdiction: "us", Window: 30 * 24 * time.Hour, LookBack: true, DeferLoss: true},
+	// Superficial losses: as in the US, the denied loss is added to the replacement
+	"ca": {Jurisdiction: "ca", Window: 30 * 24 * time.Hour, LookBack: true, DeferLoss: true},
+	// Bed and breakfasting: re-purchases within 30 days after a disposal are matched to
+	// it instead of the pool, which is flagged for review rather than deferred
+	"uk": {Jurisdiction: "uk", Window: 30 * 24 * time.Hour},
+}
+
+// WashSaleRuleFor returns a jurisdiction's rule, with its window replaced by windowDays
+// when positive. It returns nil with ok set for "none" or no jurisdiction, and ok unset
+// for an unknown jurisdiction.
+func WashSaleRuleFor(jurisdiction string, windowDays int) (rule *WashSaleRule, ok bool) {
+	jurisdiction = strings.ToLower(strings.TrimSpace(jurisdiction))
+	if jurisdiction == "" || jurisdiction == "none" {
+		return nil, true
+	}
+
+	profile, ok := washSaleRules[jurisdiction]
+	if !ok {
+		return nil, false
+	}
+	if windowDays > 0 {
+		profile.Window = time.Duration(windowDays) * 24 * time.Hour
+	}
+	return &profile, true
+}
+
+// disposal is a sale matched against buy lots, kept for wash-sale detection
+type disposal struct {
+	sell     models.PnLLot
+	quantity *big.Float
+	pnl      *big.Float
+	consumed map[int]bool // indexes of the buy lots the sale drew from
+}
+
+// covers reports whether a purchase at bought falls in the rule's window around a sale
+func (r *WashSaleRule) covers(sold, bought time.Time) bool {
+	if bought.Before(sold) {
+		return r.LookBack && sold.Sub(bought) <= r.Window
+	}
+	return bought.Sub(sold) <= r.Window
+}
+
+// detect finds losses replaced by purchases of the same token within the rule's window.
+// Each purchased unit replaces at most one sold unit, earliest losses first, and lots
+// the sale itself drew from or that arrived by internal transfer never count. Replacement
+// lots are flagged; when the rule defers losses, the replaced loss on units still held is
+// added to their cost basis and returned so it can be taken out of realized PnL.
+func (r *WashSaleRule) detect(disposals []disposal, buys []models.PnLLot) ([]models.WashSale, *big.Float, error) {
+	sort.SliceStable(disposals, func(i, j int) bool {
+		return disposals[i].sell.Timestamp.Before(disposals[j].sell.Timestamp)
+	})
+	order := make([]int, len(buys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return buys[order[i]].Timestamp.Before(buys[order[j]].Timestamp)
+	})
+
+	available := make([]*big.Float, len(buys))
+	held := make([]*big.Float, len(buys))
+	adjustments := make([]*big.Float, len(buys))
+	for i, buy := range buys {
+		quantity, _, err := big.ParseFloat(buy.Quantity, 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil, nil, err
+		}
+		remaining, _, err := big.ParseFloat(buy.RemainingQuantity, 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil, nil, err
+		}
+		available[i], held[i], adjustments[i] = quantity, remaining, new(big.Float)
+	}
+
+	var washSales []models.WashSale
+	totalDeferred := new(big.Float)
+	for _, d := range disposals {
+		if d.pnl.Sign() >= 0 || d.quantity.Sign() <= 0 {
+			continue
+		}
+		lossPerUnit := new(big.Float).Quo(d.pnl, d.quantity)
+		unreplaced := new(big.Float).Set(d.quantity)
+
+		for _, j := range order {
+			if unreplaced.Sign() <= 0 {
+				break
+			}
+			buy := buys[j]
+			if d.consumed[j] || buy.InternalTransferID != nil || available[j].Sign() <= 0 || !r.covers(d.sell.Timestamp, buy.Timestamp) {
+				continue
+			}
+
+			replaced := new(big.Float).Set(unreplaced)
+			if available[j].Cmp(replaced) < 0 {
+				replaced.Set(available[j])
+			}
+			available[j].Sub(available[j], replaced)
+			unreplaced.Sub(unreplaced, replaced)
+
+			deferred := new(big.Float)
+			if r.DeferLoss {
+				deferredQuantity := new(big.Float).Set(replaced)
+				if held[j].Cmp(deferredQuantity) < 0 {
+					deferredQuantity.Set(held[j])
+				}
+				held[j].Sub(held[j], deferredQuantity)
+				deferred.Mul(deferredQuantity, lossPerUnit).Neg(deferred)
+				adjustments[j].Add(adjustments[j], deferred)
+				totalDeferred.Add(totalDeferred, deferred)
+			}
+
+			buys[j].WashSale = true
+			washSales = append(washSales, models.WashSale{
+				SellTransactionHash:        d.sell.TransactionHash,
+				SoldAt:                     d.sell.Timestamp,
+				ReplacementTransactionHash: buy.TransactionHash,
+				ReplacedAt:                 buy.Timestamp,
+				Quantity:                   replaced.String(),
+				LossUSD:                    new(big.Float).Mul(replaced, lossPerUnit).String(),
+				DeferredLossUSD:            deferred.String(),
+			})
+		}
+	}
+
+	for j, adjustment := range adjustments {
+		if adjustment.Sign() <= 0 {
+			continue
+		}
+		remaining, _, err := big.ParseFloat(buys[j].RemainingQuantity, 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil, nil, err
+		}
+		cost, _, err := big.ParseFloat(lotCost(buys[j]), 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil, nil, err
+		}
+		cost.Add(cost, new(big.Float).Quo(adjustment, remaining))
+		costBasis := cost.Text('f', 10)
+		adjustmentUSD := adjustment.String()
+		buys[j].CostBasisUSD = &costBasis
+		buys[j].WashSaleAdjustmentUSD = &adjustmentUSD
+	}
+
+	return washSales, totalDeferred, nil
+}
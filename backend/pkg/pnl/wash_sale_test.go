@@ -0,0 +1,84 @@
+package pnl
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func washSaleLots(at time.Time) []models.PnLLot {
+	day := 24 * time.Hour
+	return []models.PnLLot{
+		{ID: uuid.New(), TransactionHash: "0xbuy", Type: "buy", Quantity: "10", PriceUSD: "100", RemainingQuantity: "10", Timestamp: at},
+		// Sold at a loss of 40 a unit
+		{ID: uuid.New(), TransactionHash: "0xsell", Type: "sell", Quantity: "10", PriceUSD: "60", RemainingQuantity: "10", Timestamp: at.Add(10 * day)},
+		// Re-purchased half of it ten days later
+		{ID: uuid.New(), TransactionHash: "0xrebuy", Type: "buy", Quantity: "5", PriceUSD: "65", RemainingQuantity: "5", Timestamp: at.Add(20 * day)},
+	}
+}
+
+func TestWashSale_DeferredLoss(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rule, ok := WashSaleRuleFor("US", 0)
+	require.True(t, ok)
+
+	calculation, err := NewCalculatorWithWashSales(FIFO, rule).CalculatePnL(washSaleLots(at), "70")
+	require.NoError(t, err)
+	assert.Equal(t, "us", calculation.Jurisdiction)
+	require.Len(t, calculation.WashSales, 1)
+	washSale := calculation.WashSales[0]
+	assert.Equal(t, "0xsell", washSale.SellTransactionHash)
+	assert.Equal(t, "0xrebuy", washSale.ReplacementTransactionHash)
+	assert.Equal(t, "5", washSale.Quantity)
+	assert.Equal(t, "-200", washSale.LossUSD)
+
+	// Only the unreplaced half of the 400 loss is realized; the rest moves into the
+	// replacement's basis
+	assert.Equal(t, "200", calculation.DeferredLossUSD)
+	assert.Equal(t, "-200", calculation.RealizedPnLUSD)
+	replacement := calculation.Lots[1]
+	assert.True(t, replacement.WashSale)
+	assert.Equal(t, "105.0000000000", *replacement.CostBasisUSD)
+	costBasis, err := strconv.ParseFloat(calculation.TotalCostBasisUSD, 64)
+	require.NoError(t, err)
+	assert.InDelta(t, 525, costBasis, 0.0001)
+}
+
+func TestWashSale_FlagOnlyAndWindow(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// The UK rule flags without deferring
+	rule, ok := WashSaleRuleFor("uk", 0)
+	require.True(t, ok)
+	calculation, err := NewCalculatorWithWashSales(FIFO, rule).CalculatePnL(washSaleLots(at), "70")
+	require.NoError(t, err)
+	require.Len(t, calculation.WashSales, 1)
+	assert.Equal(t, "-400", calculation.RealizedPnLUSD)
+	assert.Empty(t, calculation.DeferredLossUSD)
+	assert.Nil(t, calculation.Lots[1].CostBasisUSD)
+
+	// A narrower window misses the re-purchase
+	rule, _ = WashSaleRuleFor("us", 5)
+	calculation, err = NewCalculatorWithWashSales(FIFO, rule).CalculatePnL(washSaleLots(at), "70")
+	require.NoError(t, err)
+	assert.Empty(t, calculation.WashSales)
+	assert.Equal(t, "-400", calculation.RealizedPnLUSD)
+
+	// A purchase drawn on by the losing sale is no replacement
+	rule, _ = WashSaleRuleFor("us", 0)
+	lots := washSaleLots(at)[:2]
+	calculation, err = NewCalculatorWithWashSales(FIFO, rule).CalculatePnL(lots, "70")
+	require.NoError(t, err)
+	assert.Empty(t, calculation.WashSales)
+
+	rule, ok = WashSaleRuleFor("none", 0)
+	assert.True(t, ok)
+	assert.Nil(t, rule)
+	_, ok = WashSaleRuleFor("atlantis", 0)
+	assert.False(t, ok)
+}
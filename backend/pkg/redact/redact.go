@@ -0,0 +1,162 @@
+// Package redact strips credentials and personal data from request and response bodies
+// before they're kept for debugging: signatures, tokens, secrets, webhook URLs and email
+// addresses.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces a sensitive value
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are field and parameter names whose values are always dropped, compared
+// lowercased with separators removed
+var sensitiveKeys = map[string]bool{
+	"token":             true,
+	"accesstoken":       true,
+	"refreshtoken":      true,
+	"idtoken":           true,
+	"authtoken":         true,
+	"sessiontoken":      true,
+	"verificationtoken": true,
+	"csrftoken":         true,
+	"jwt":               true,
+	"authorization":     true,
+	"sig":               true,
+	"mnemonic":          true,
+	"seed":              true,
+	"email":             true,
+}
+
+// sensitiveSuffixes catch the variants of the keys above, such as apiSecret or
+// webhook_url; names like fromToken are token addresses and are kept
+var sensitiveSuffixes = []string{
+	"signature",
+	"secret",
+	"password",
+	"passphrase",
+	"apikey",
+	"privatekey",
+	"webhook",
+	"webhookurl",
+}
+
+var (
+	emailPattern          = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	signaturePattern      = regexp.MustCompile(`0x[0-9a-fA-F]{130}`)
+	jwtPattern            = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	bearerPattern         = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/\-]+=*`)
+	keySegmentPattern     = regexp.MustCompile(`^[A-Za-z0-9_\-]{24,}$`)
+	versionSegmentPattern = regexp.MustCompile(`^v[0-9]+$`)
+	webhookPattern        = regexp.MustCompile(`(?i)https?://(hooks\.slack\.com|(discord|discordapp)\.com/api/webhooks|[A-Za-z0-9.\-]+\.webhook\.office\.com)/\S*`)
+)
+
+// SensitiveKey reports whether a field or parameter name holds a value to drop
+func SensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	k = strings.NewReplacer("_", "", "-", "", ".", "").Replace(k)
+	if sensitiveKeys[k] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(k, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text masks signatures, tokens, webhook URLs and email addresses found anywhere in s
+func Text(s string) string {
+	s = webhookPattern.ReplaceAllString(s, Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	s = signaturePattern.ReplaceAllString(s, Redacted)
+	return emailPattern.ReplaceAllString(s, Redacted)
+}
+
+// JSON drops the values of sensitive fields at any depth and masks the rest like Text.
+// A body that isn't JSON is masked as text.
+func JSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return []byte(Text(string(body)))
+	}
+
+	out, err := json.Marshal(value(v))
+	if err != nil {
+		return []byte(Text(string(body)))
+	}
+	return out
+}
+
+// Query drops the values of sensitive parameters in a raw query string and masks the
+// rest like Text
+func Query(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return Text(raw)
+	}
+	for key, vs := range values {
+		for i := range vs {
+			if SensitiveKey(key) {
+				vs[i] = Redacted
+			} else {
+				vs[i] = Text(vs[i])
+			}
+		}
+	}
+	// Encode escapes the brackets of the placeholder; they're kept readable
+	return strings.ReplaceAll(values.Encode(), url.QueryEscape(Redacted), Redacted)
+}
+
+// Path masks API keys passed as the path segment after the API version, as Alchemy
+// and Infura take them (/v2/<key>)
+func Path(path string) string {
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		segment := segments[i]
+		if versionSegmentPattern.MatchString(segments[i-1]) && keySegmentPattern.MatchString(segment) &&
+			strings.ContainsAny(segment, "0123456789") && strings.IndexFunc(segment, isLetter) >= 0 {
+			segments[i] = Redacted
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if SensitiveKey(key) && field != nil {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = value(field)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = value(v[i])
+		}
+		return v
+	case string:
+		return Text(v)
+	default:
+		return v
+	}
+}
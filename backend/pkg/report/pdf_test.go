@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertValidXref checks that every xref entry points at the object it numbers
+func assertValidXref(t *testing.T, pdf []byte) {
+	t.Helper()
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	require.NotNil(t, m, "missing startxref trailer")
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestDocument_Bytes(t *testing.T) {
+	doc := NewDocument("Test")
+	doc.Text(50, 800, FontBold, 12, "Balance (USD) \\ total")
+	doc.AddPage()
+	doc.Text(50, 800, FontRegular, 10, "Ünïcode")
+
+	pdf := doc.Bytes()
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.Contains(t, string(pdf), `(Balance \(USD\) \\ total) Tj`)
+	assert.Contains(t, string(pdf), "(?n?code) Tj")
+	assert.Contains(t, string(pdf), "/Count 2")
+	assertValidXref(t, pdf)
+}
+
+func TestDocument_EmptyHasOnePage(t *testing.T) {
+	pdf := NewDocument("Empty").Bytes()
+	assert.Contains(t, string(pdf), "/Count 1")
+	assertValidXref(t, pdf)
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 5.56, TextWidth("a", FontRegular, 10), 1e-9)
+	assert.InDelta(t, 6.11, TextWidth("b", FontBold, 10), 1e-9)
+	assert.Greater(t, TextWidth("Total", FontBold, 10), TextWidth("Total", FontRegular, 10))
+}
+
+func TestFormatUSD(t *testing.T) {
+	assert.Equal(t, "$0.00", formatUSD(decimal.Zero))
+	assert.Equal(t, "$1,234,567.89", formatUSD(decimal.MustParse("1234567.891")))
+	assert.Equal(t, "-$1,000.50", formatUSD(decimal.MustParse("-1000.499")))
+	assert.Equal(t, "$1.00", formatUSD(decimal.MustParse("0.999")))
+	assert.Equal(t, "$0.00", formatUSD(decimal.MustParse("-0.001")))
+	assert.Equal(t, "$0.30", formatUSD(decimal.MustParse("0.1").Add(decimal.MustParse("0.2"))))
+}
+
+func TestFormatQuantity(t *testing.T) {
+	assert.Equal(t, "1,500", formatQuantity(1500))
+	assert.Equal(t, "0.12345679", formatQuantity(0.123456789))
+	assert.Equal(t, "2,000.5", formatQuantity(2000.5))
+}
+
+func TestRenderStatement_PaginatesLongHoldings(t *testing.T) {
+	opening := decimal.NewFromInt(900)
+	data := &models.StatementData{
+		PeriodStart:     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		GeneratedAt:     time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC),
+		TotalValueUSD:   decimal.NewFromInt(1000),
+		OpeningValueUSD: &opening,
+		RealizedPnL:     []models.StatementPnL{{Source: "0xabc", RealizedPnLUSD: decimal.MustParse("12.5")}},
+		Fees:            []models.StatementFeeSpend{{ChainID: 1, TxCount: 3, FeeUSD: decimal.MustParse("4.2")}},
+		Income:          []models.StatementIncome{{Category: models.IncomeStakingReward, ValueUSD: decimal.NewFromInt(8)}},
+		TotalIncomeUSD:  decimal.NewFromInt(8),
+	}
+	for i := 0; i < 80; i++ {
+		data.Holdings = append(data.Holdings, models.StatementHolding{Source: "0x1234567890abcdef1234567890abcdef12345678", Symbol: "ETH", Quantity: 0.1, ValueUSD: decimal.MustParse("12.5")})
+	}
+
+	pdf := RenderStatement(data)
+	out := string(pdf)
+	assert.Contains(t, out, "(March 2026) Tj")
+	assert.Contains(t, out, "+11.11%")
+	assert.Contains(t, out, "(Ethereum) Tj")
+	assert.Contains(t, out, "(Staking reward) Tj")
+	assert.Contains(t, out, "(Page 2) Tj")
+	// The holdings header repeats on the continuation page
+	assert.Greater(t, bytes.Count(pdf, []byte("(Value \\(USD\\)) Tj")), 1)
+	assertValidXref(t, pdf)
+}
@@ -0,0 +1,289 @@
+package report
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+)
+
+const (
+	margin     = 50.0
+	bodySize   = 9.0
+	lineHeight = 14.0
+	// sourceWidth bounds the source column, which holds addresses and account labels
+	sourceWidth = 190.0
+)
+
+// column is a table column; numeric columns are right-aligned at their right edge
+type column struct {
+	title string
+	x     float64
+	right bool
+}
+
+var (
+	holdingColumns = []column{
+		{title: "Source", x: margin},
+		{title: "Asset", x: 250},
+		{title: "Quantity", x: 440, right: true},
+		{title: "Value (USD)", x: PageWidth - margin, right: true},
+	}
+	pnlColumns = []column{
+		{title: "Source", x: margin},
+		{title: "Realized PnL (USD)", x: PageWidth - margin, right: true},
+	}
+	incomeColumns = []column{
+		{title: "Category", x: margin},
+		{title: "Value on receipt (USD)", x: PageWidth - margin, right: true},
+	}
+	feeColumns = []column{
+		{title: "Chain", x: margin},
+		{title: "Transactions", x: 400, right: true},
+		{title: "Fees (USD)", x: PageWidth - margin, right: true},
+	}
+)
+
+// statementWriter lays out a statement top to bottom, breaking onto new pages as needed
+type statementWriter struct {
+	doc    *Document
+	y      float64
+	header []column // repeated at the top of a page when a table breaks across it
+}
+
+// RenderStatement renders a monthly portfolio statement as a PDF
+func RenderStatement(data *models.StatementData) []byte {
+	period := data.PeriodStart.UTC().Format("January 2006")
+	w := &statementWriter{doc: NewDocument("Portfolio Statement - " + period)}
+	w.newPage()
+
+	w.doc.Text(margin, w.y, FontBold, 18, "Portfolio Statement")
+	w.y -= 22
+	w.doc.Text(margin, w.y, FontRegular, 11, period)
+	w.y -= 14
+	w.doc.Text(margin, w.y, FontRegular, 8, fmt.Sprintf("%s to %s UTC, generated %s",
+		data.PeriodStart.UTC().Format("2006-01-02"),
+		data.PeriodEnd.UTC().Format("2006-01-02"),
+		data.GeneratedAt.UTC().Format("2006-01-02 15:04")))
+	w.y -= 28
+
+	w.section("Summary")
+	w.summaryRow("Portfolio value", formatUSD(data.TotalValueUSD))
+	if data.OpeningValueUSD != nil {
+		opening := *data.OpeningValueUSD
+		w.summaryRow("Previous statement value", formatUSD(opening))
+		difference := data.TotalValueUSD.Sub(opening)
+		change := formatUSD(difference)
+		if opening.Sign() > 0 {
+			percent := difference.Mul(decimal.NewFromInt(100)).DivRound(opening, 2)
+			if percent.Sign() >= 0 {
+				change += " (+" + percent.StringFixed(2) + "%)"
+			} else {
+				change += " (" + percent.StringFixed(2) + "%)"
+			}
+		}
+		w.summaryRow("Change", change)
+	}
+	w.summaryRow("Realized PnL", formatUSD(data.TotalRealizedPnLUSD))
+	w.summaryRow("Income", formatUSD(data.TotalIncomeUSD))
+	w.summaryRow("Gas fees paid", formatUSD(data.TotalFeesUSD))
+	w.y -= lineHeight
+
+	w.section("Holdings")
+	if len(data.Holdings) == 0 {
+		w.note("No holdings.")
+	} else {
+		w.tableHeader(holdingColumns)
+		for _, h := range data.Holdings {
+			w.row(holdingColumns, truncate(h.Source, sourceWidth), h.Symbol, formatQuantity(h.Quantity), formatUSD(h.ValueUSD))
+		}
+		w.totalRow(holdingColumns, formatUSD(data.TotalValueUSD))
+	}
+	w.y -= lineHeight
+
+	w.section("Realized PnL")
+	if len(data.RealizedPnL) == 0 {
+		w.note("No disposals in this period.")
+	} else {
+		w.tableHeader(pnlColumns)
+		for _, p := range data.RealizedPnL {
+			w.row(pnlColumns, truncate(p.Source, 400), formatUSD(p.RealizedPnLUSD))
+		}
+		w.totalRow(pnlColumns, formatUSD(data.TotalRealizedPnLUSD))
+	}
+	w.y -= lineHeight
+
+	w.section("Income")
+	if len(data.Income) == 0 {
+		w.note("No income received in this period.")
+	} else {
+		w.tableHeader(incomeColumns)
+		for _, i := range data.Income {
+			w.row(incomeColumns, incomeCategoryName(i.Category), formatUSD(i.ValueUSD))
+		}
+		w.totalRow(incomeColumns, formatUSD(data.TotalIncomeUSD))
+	}
+	w.y -= lineHeight
+
+	w.section("Fee spend")
+	if len(data.Fees) == 0 {
+		w.note("No transactions sent in this period.")
+	} else {
+		w.tableHeader(feeColumns)
+		for _, f := range data.Fees {
+			w.row(feeColumns, blockchain.GetChainName(f.ChainID), strconv.Itoa(f.TxCount), formatUSD(f.FeeUSD))
+		}
+		w.totalRow(feeColumns, formatUSD(data.TotalFeesUSD))
+	}
+
+	w.header = nil
+	w.ensure(3 * lineHeight)
+	w.y -= 2 * lineHeight
+	w.doc.Text(margin, w.y, FontRegular, 7, "Holdings are valued at the time of generation. Values are estimates from market data and are not tax advice.")
+
+	return w.doc.Bytes()
+}
+
+func (w *statementWriter) newPage() {
+	w.doc.AddPage()
+	w.y = PageHeight - margin
+	w.doc.TextRight(PageWidth-margin, margin/2, FontRegular, 7, fmt.Sprintf("Page %d", w.doc.PageCount()))
+	if w.header != nil {
+		w.tableHeader(w.header)
+	}
+}
+
+// ensure starts a new page unless height fits above the bottom margin
+func (w *statementWriter) ensure(height float64) {
+	if w.y-height < margin {
+		w.newPage()
+	}
+}
+
+func (w *statementWriter) section(title string) {
+	w.header = nil
+	// Keep a heading with at least its table header and first row
+	w.ensure(4 * lineHeight)
+	w.doc.Text(margin, w.y, FontBold, 12, title)
+	w.y -= 6
+	w.doc.Line(margin, w.y, PageWidth-margin, w.y)
+	w.y -= lineHeight
+}
+
+func (w *statementWriter) note(text string) {
+	w.doc.Text(margin, w.y, FontRegular, bodySize, text)
+	w.y -= lineHeight
+}
+
+func (w *statementWriter) summaryRow(label, value string) {
+	w.doc.Text(margin, w.y, FontRegular, bodySize+1, label)
+	w.doc.TextRight(300, w.y, FontBold, bodySize+1, value)
+	w.y -= lineHeight + 2
+}
+
+func (w *statementWriter) tableHeader(columns []column) {
+	w.header = columns
+	w.cells(columns, FontBold, titles(columns))
+	w.doc.Line(margin, w.y+lineHeight-4, PageWidth-margin, w.y+lineHeight-4)
+}
+
+func (w *statementWriter) row(columns []column, values ...string) {
+	w.ensure(lineHeight)
+	w.cells(columns, FontRegular, values)
+}
+
+func (w *statementWriter) totalRow(columns []column, total string) {
+	w.ensure(lineHeight)
+	w.doc.Line(margin, w.y+lineHeight-4, PageWidth-margin, w.y+lineHeight-4)
+	values := make([]string, len(columns))
+	values[0] = "Total"
+	values[len(values)-1] = total
+	w.cells(columns, FontBold, values)
+}
+
+func (w *statementWriter) cells(columns []column, font Font, values []string) {
+	for i, col := range columns {
+		if values[i] == "" {
+			continue
+		}
+		if col.right {
+			w.doc.TextRight(col.x, w.y, font, bodySize, values[i])
+		} else {
+			w.doc.Text(col.x, w.y, font, bodySize, values[i])
+		}
+	}
+	w.y -= lineHeight
+}
+
+func titles(columns []column) []string {
+	out := make([]string, len(columns))
+	for i, col := range columns {
+		out[i] = col.title
+	}
+	return out
+}
+
+// truncate shortens s with an ellipsis to fit within width points
+func truncate(s string, width float64) string {
+	if TextWidth(s, FontRegular, bodySize) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && TextWidth(string(runes)+"...", FontRegular, bodySize) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// incomeCategoryName turns an income category into a label, e.g. staking_reward ->
+// Staking reward
+func incomeCategoryName(category string) string {
+	if category == "" {
+		return category
+	}
+	label := strings.ReplaceAll(category, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// formatUSD formats v as dollars with thousands separators, e.g. -$1,234.50
+func formatUSD(v decimal.Decimal) string {
+	sign := ""
+	if v.Round(2).Sign() < 0 {
+		sign = "-"
+	}
+	whole, cents, _ := strings.Cut(v.Abs().StringFixed(2), ".")
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + "$" + whole + "." + cents
+}
+
+// formatQuantity formats a token quantity with up to 8 significant decimals
+func formatQuantity(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 8, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	whole, frac, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return s
+	}
+	if frac == "" {
+		return groupThousands(n)
+	}
+	return groupThousands(n) + "." + frac
+}
+
+func groupThousands(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s
+}
@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/defi-dashboard/backend/pkg/logger"
+)
+
+// Manager caches secrets from a Provider and notifies subscribers when a value rotates
+type Manager struct {
+	provider    Provider
+	mu          sync.RWMutex
+	values      map[string]string
+	subscribers map[string][]func(string)
+}
+
+func NewManager(provider Provider) *Manager {
+	return &Manager{
+		provider:    provider,
+		values:      make(map[string]string),
+		subscribers: make(map[string][]func(string)),
+	}
+}
+
+// Get returns the cached value for key, or "" if it isn't set
+func (m *Manager) Get(key string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.values[key]
+}
+
+// OnChange registers fn to be called with the new value whenever key changes on refresh
+func (m *Manager) OnChange(key string, fn func(string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers[key] = append(m.subscribers[key], fn)
+}
+
+// Refresh fetches secrets from the provider and notifies subscribers of changed keys.
+// Keys missing from the provider response keep their previous value.
+func (m *Manager) Refresh(ctx context.Context) error {
+	fetched, err := m.provider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	type change struct {
+		key   string
+		value string
+		fns   []func(string)
+	}
+	var changes []change
+
+	m.mu.Lock()
+	for key, value := range fetched {
+		if value == "" || m.values[key] == value {
+			continue
+		}
+		m.values[key] = value
+		changes = append(changes, change{key: key, value: value, fns: m.subscribers[key]})
+	}
+	m.mu.Unlock()
+
+	for _, c := range changes {
+		logger.Info("Secret rotated", "provider", m.provider.Name(), "key", c.key)
+		for _, fn := range c.fns {
+			fn(c.value)
+		}
+	}
+
+	return nil
+}
+
+// Start refreshes secrets on the given interval until ctx is cancelled
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.Refresh(ctx); err != nil {
+					logger.Error("Failed to refresh secrets", "provider", m.provider.Name(), "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticProvider struct {
+	values map[string]string
+}
+
+func (p *staticProvider) Name() string { return "static" }
+
+func (p *staticProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	return p.values, nil
+}
+
+func TestManager_RefreshNotifiesOnRotation(t *testing.T) {
+	provider := &staticProvider{values: map[string]string{"ALCHEMY_API_KEY": "key-1"}}
+	manager := NewManager(provider)
+
+	var rotated []string
+	manager.OnChange("ALCHEMY_API_KEY", func(v string) { rotated = append(rotated, v) })
+
+	require.NoError(t, manager.Refresh(context.Background()))
+	assert.Equal(t, "key-1", manager.Get("ALCHEMY_API_KEY"))
+
+	// Unchanged value does not notify
+	require.NoError(t, manager.Refresh(context.Background()))
+
+	provider.values = map[string]string{"ALCHEMY_API_KEY": "key-2"}
+	require.NoError(t, manager.Refresh(context.Background()))
+	assert.Equal(t, "key-2", manager.Get("ALCHEMY_API_KEY"))
+
+	// A key dropped from the provider keeps its last value
+	provider.values = map[string]string{}
+	require.NoError(t, manager.Refresh(context.Background()))
+	assert.Equal(t, "key-2", manager.Get("ALCHEMY_API_KEY"))
+
+	assert.Equal(t, []string{"key-1", "key-2"}, rotated)
+}
+
+func TestVaultProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/secret/data/defi-dashboard", r.URL.Path)
+		assert.Equal(t, "s.token", r.Header.Get("X-Vault-Token"))
+		w.Write([]byte(`{"data":{"data":{"ZEROX_API_KEY":"zx","LIFI_API_KEY":"lf","ttl":30}}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewVaultProvider(server.URL, "s.token", "secret/defi-dashboard")
+	require.NoError(t, err)
+
+	values, err := provider.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ZEROX_API_KEY": "zx", "LIFI_API_KEY": "lf"}, values)
+}
+
+func TestAWSSecretsManagerProvider_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/secretsmanager/aws4_request")
+		w.Write([]byte(`{"SecretString":"{\"ALCHEMY_API_KEY\":\"al\"}"}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewAWSSecretsManagerProvider("us-east-1", "defi-dashboard", "AKID", "secret", "")
+	require.NoError(t, err)
+	provider.endpoint = server.URL + "/"
+
+	values, err := provider.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "al", values["ALCHEMY_API_KEY"])
+}
@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Provider fetches the current set of secrets as key/value pairs.
+// Keys use the same names as the environment variables they replace (e.g. ALCHEMY_API_KEY).
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// EnvProvider reads a fixed list of keys from the process environment
+type EnvProvider struct {
+	keys []string
+}
+
+func NewEnvProvider(keys []string) *EnvProvider {
+	return &EnvProvider{keys: keys}
+}
+
+func (p *EnvProvider) Name() string { return "env" }
+
+func (p *EnvProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string, len(p.keys))
+	for _, key := range p.keys {
+		if v := os.Getenv(key); v != "" {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+// VaultProvider reads a HashiCorp Vault KV v2 secret
+type VaultProvider struct {
+	httpClient *http.Client
+	addr       string
+	token      string
+	mount      string
+	path       string
+}
+
+// NewVaultProvider creates a Vault provider. secretPath is "<mount>/<path>", e.g. "secret/defi-dashboard".
+func NewVaultProvider(addr, token, secretPath string) (*VaultProvider, error) {
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("VAULT_ADDR and VAULT_TOKEN are required")
+	}
+
+	mount, path, ok := strings.Cut(strings.Trim(secretPath, "/"), "/")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid vault secret path %q, expected <mount>/<path>", secretPath)
+	}
+
+	return &VaultProvider{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		addr:       strings.TrimRight(addr, "/"),
+		token:      token,
+		mount:      mount,
+		path:       path,
+	}, nil
+}
+
+func (p *VaultProvider) Name() string { return "vault" }
+
+func (p *VaultProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	url := fmt.Sprintf("%s/v1/%s/data/%s", p.addr, p.mount, p.path)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault response: %w", err)
+	}
+
+	return stringValues(body.Data.Data), nil
+}
+
+// stringValues keeps the string-valued entries of a decoded JSON object
+func stringValues(data map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			values[k] = s
+		}
+	}
+	return values
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Storage backends
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendMinIO = "minio"
+	BackendGCS   = "gcs"
+)
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("object not found")
+
+// Store is an object store for generated and uploaded files, addressed by
+// slash-separated keys
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL from which the object can be downloaded without further
+	// authentication until expiry has passed
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Config selects and configures a backend
+type Config struct {
+	Backend string
+
+	// Local backend: files live under LocalDir and are served by the API at LocalBaseURL
+	// with URLs signed by SigningKey
+	LocalDir     string
+	LocalBaseURL string
+	SigningKey   []byte
+
+	// S3-compatible backends. Endpoint defaults to AWS for s3 and to Google's XML API for
+	// gcs, which accepts SigV4 with HMAC interoperability keys; minio requires it.
+	Bucket          string
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool
+}
+
+// New builds the store for cfg.Backend
+func New(cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		if len(cfg.SigningKey) == 0 {
+			return nil, fmt.Errorf("a signing key is required for local storage")
+		}
+		return NewLocalStore(cfg.LocalDir, cfg.LocalBaseURL, cfg.SigningKey), nil
+	case BackendS3:
+		region := cfg.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return NewS3Store(endpoint, cfg.Bucket, region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.PathStyle)
+	case BackendMinIO:
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("an endpoint is required for minio storage")
+		}
+		region := cfg.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		// MinIO serves buckets by path unless a wildcard domain is configured
+		return NewS3Store(cfg.Endpoint, cfg.Bucket, region, cfg.AccessKeyID, cfg.SecretAccessKey, true)
+	case BackendGCS:
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		region := cfg.Region
+		if region == "" {
+			region = "auto"
+		}
+		return NewS3Store(endpoint, cfg.Bucket, region, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.PathStyle)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// validateKey rejects keys that are empty, absolute or step outside the store
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key: %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid storage key: %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package websocket adapts github.com/fasthttp/websocket to fiber handlers. It covers
+// what the realtime feed needs: checking the handshake's Origin, sending text messages
+// and reading client messages, with pings answered by the library.
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	ws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Message types, numbered as the RFC 6455 opcodes
+const (
+	OpText   = ws.TextMessage
+	OpBinary = ws.BinaryMessage
+	OpClose  = ws.CloseMessage
+	OpPing   = ws.PingMessage
+	OpPong   = ws.PongMessage
+)
+
+const (
+	maxMessageSize = 64 << 10
+	writeTimeout   = 10 * time.Second
+)
+
+var (
+	// ErrClosed is returned by ReadMessage once the peer has closed the connection
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrBadHandshake is returned by Upgrade for requests that are not valid websocket handshakes
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	// ErrOriginNotAllowed is returned by Upgrade for browser requests from an origin
+	// that isn't allowed
+	ErrOriginNotAllowed = errors.New("websocket: origin not allowed")
+)
+
+// IsUpgrade reports whether the request asks to upgrade to a websocket
+func IsUpgrade(c *fiber.Ctx) bool {
+	return ws.FastHTTPIsWebSocketUpgrade(c.Context())
+}
+
+// OriginAllowed reports whether a handshake's Origin header is acceptable. Requests
+// without one don't come from a browser and are allowed; otherwise the origin must be
+// in allowed, which may contain "*" to allow any, or be the host the request was sent to.
+func OriginAllowed(origin, host string, allowed []string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, entry := range allowed {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" || strings.EqualFold(strings.TrimSuffix(entry, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, host)
+}
+
+// Upgrade checks the request's origin against allowedOrigins, completes the handshake
+// and runs handler on the upgraded connection once the fiber handler returns. The
+// connection is closed when handler returns. Failed handshakes write no response,
+// leaving it to the error the fiber handler returns.
+func Upgrade(c *fiber.Ctx, allowedOrigins []string, handler func(*Conn)) error {
+	if !OriginAllowed(c.Get(fiber.HeaderOrigin), string(c.Request().Host()), allowedOrigins) {
+		return ErrOriginNotAllowed
+	}
+
+	upgrader := ws.FastHTTPUpgrader{
+		// The origin was checked above, with the configured origins as well as the host
+		CheckOrigin: func(*fasthttp.RequestCtx) bool { return true },
+		Error:       func(*fasthttp.RequestCtx, int, error) {},
+	}
+	err := upgrader.Upgrade(c.Context(), func(conn *ws.Conn) {
+		conn.SetReadLimit(maxMessageSize)
+		wrapped := &Conn{conn: conn}
+		defer wrapped.Close()
+		handler(wrapped)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadHandshake, err)
+	}
+	return nil
+}
+
+// Conn is an upgraded websocket connection. Writes are safe for concurrent use;
+// reads must happen from a single goroutine.
+type Conn struct {
+	conn    *ws.Conn
+	writeMu sync.Mutex
+}
+
+// WriteMessage sends a single message of the given type
+func (c *Conn) WriteMessage(messageType int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.conn.WriteMessage(messageType, payload)
+}
+
+// WriteJSON sends v as a JSON text message
+func (c *Conn) WriteJSON(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return c.WriteMessage(OpText, payload)
+}
+
+// ReadMessage returns the next text or binary message. Pings are answered and pongs
+// skipped by the library; a close from the peer is reported as ErrClosed.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType, message, err := c.conn.ReadMessage()
+	var closeErr *ws.CloseError
+	if errors.As(err, &closeErr) {
+		return 0, nil, ErrClosed
+	}
+	return messageType, message, err
+}
+
+// SetReadDeadline sets the deadline for the next ReadMessage
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// Close sends a normal-closure frame and closes the connection
+func (c *Conn) Close() error {
+	c.writeMu.Lock()
+	c.conn.WriteControl(OpClose, ws.FormatCloseMessage(ws.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+	c.writeMu.Unlock()
+	return c.conn.Close()
+}
@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	ws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.example.com", " http://localhost:5173/"}
+
+	// Non-browser clients send no Origin
+	assert.True(t, OriginAllowed("", "api.example.com", allowed))
+	assert.True(t, OriginAllowed("https://app.example.com", "api.example.com", allowed))
+	assert.True(t, OriginAllowed("http://localhost:5173", "api.example.com", allowed))
+	// Same-origin pages are allowed whatever the list says
+	assert.True(t, OriginAllowed("https://api.example.com", "api.example.com", nil))
+
+	assert.False(t, OriginAllowed("https://evil.example.com", "api.example.com", allowed))
+	assert.False(t, OriginAllowed("https://app.example.com.evil.com", "api.example.com", allowed))
+	assert.False(t, OriginAllowed("null", "api.example.com", allowed))
+
+	assert.True(t, OriginAllowed("https://evil.example.com", "api.example.com", []string{"*"}))
+}
+
+// serve runs an app echoing messages over /ws until the test ends and returns its address
+func serve(t *testing.T, allowedOrigins []string) string {
+	app := fiber.New()
+	app.Get("/ws", func(c *fiber.Ctx) error {
+		err := Upgrade(c, allowedOrigins, func(conn *Conn) {
+			for {
+				op, msg, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				if err := conn.WriteMessage(op, msg); err != nil {
+					return
+				}
+			}
+		})
+		if errors.Is(err, ErrOriginNotAllowed) {
+			return fiber.ErrForbidden
+		}
+		if err != nil {
+			return fiber.ErrBadRequest
+		}
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return ln.Addr().String()
+}
+
+func TestUpgrade(t *testing.T) {
+	addr := serve(t, []string{"https://app.example.com"})
+
+	header := http.Header{"Origin": {"https://app.example.com"}}
+	client, _, err := ws.DefaultDialer.Dial("ws://"+addr+"/ws", header)
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Pings are answered by the library while messages are echoed
+	pong := make(chan string, 1)
+	client.SetPongHandler(func(data string) error {
+		pong <- data
+		return nil
+	})
+	require.NoError(t, client.WriteMessage(ws.PingMessage, []byte("hi")))
+	require.NoError(t, client.WriteMessage(ws.TextMessage, []byte("hello world")))
+
+	op, msg, err := client.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, OpText, op)
+	assert.Equal(t, "hello world", string(msg))
+	assert.Equal(t, "hi", <-pong)
+
+	// Closing from the client ends the server's read loop, which closes normally
+	require.NoError(t, client.WriteMessage(ws.CloseMessage, ws.FormatCloseMessage(ws.CloseNormalClosure, "")))
+	_, _, err = client.ReadMessage()
+	assert.True(t, ws.IsCloseError(err, ws.CloseNormalClosure), "got %v", err)
+}
+
+func TestUpgrade_RejectsOrigin(t *testing.T) {
+	addr := serve(t, []string{"https://app.example.com"})
+
+	header := http.Header{"Origin": {"https://evil.example.com"}}
+	_, resp, err := ws.DefaultDialer.Dial("ws://"+addr+"/ws", header)
+	require.ErrorIs(t, err, ws.ErrBadHandshake)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestUpgrade_RejectsPlainRequest(t *testing.T) {
+	addr := serve(t, nil)
+
+	resp, err := http.Get("http://" + addr + "/ws")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
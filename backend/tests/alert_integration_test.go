@@ -53,6 +53,22 @@ func (m *MockAlertService) TriggerAlert(ctx context.Context, alertID uuid.UUID,
 	return args.Error(0)
 }
 
+func (m *MockAlertService) MuteAlert(ctx context.Context, alertID uuid.UUID, userID uuid.UUID, until *time.Time) (*models.Alert, error) {
+	args := m.Called(ctx, alertID, userID, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Alert), args.Error(1)
+}
+
+func (m *MockAlertService) GetAlertStats(ctx context.Context, userID uuid.UUID, period string) (*models.AlertStats, error) {
+	args := m.Called(ctx, userID, period)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AlertStats), args.Error(1)
+}
+
 type MockAlertRepository struct {
 	mock.Mock
 }
@@ -92,11 +108,37 @@ func (m *MockAlertRepository) UpdateTriggered(ctx context.Context, alertID uuid.
 	return args.Error(0)
 }
 
+func (m *MockAlertRepository) SetMutedUntil(ctx context.Context, alertID uuid.UUID, mutedUntil *time.Time) error {
+	args := m.Called(ctx, alertID, mutedUntil)
+	return args.Error(0)
+}
+
+func (m *MockAlertRepository) GetStats(ctx context.Context, userID uuid.UUID, since time.Time, bucket string, noisiestLimit int) (*models.AlertStats, error) {
+	args := m.Called(ctx, userID, since, bucket, noisiestLimit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AlertStats), args.Error(1)
+}
+
+func (m *MockAlertRepository) GetTriggersSince(ctx context.Context, userID uuid.UUID, since time.Time, limit int) ([]models.TriggeredAlert, error) {
+	args := m.Called(ctx, userID, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TriggeredAlert), args.Error(1)
+}
+
 func (m *MockAlertRepository) CreateHistory(ctx context.Context, history *models.AlertHistory) error {
 	args := m.Called(ctx, history)
 	return args.Error(0)
 }
 
+func (m *MockAlertRepository) RecordTrigger(ctx context.Context, history *models.AlertHistory, notification models.AlertNotification, urgent bool) (uuid.UUID, error) {
+	args := m.Called(ctx, history, notification, urgent)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
 func (m *MockAlertRepository) GetHistory(ctx context.Context, alertID *uuid.UUID, limit, offset int) ([]models.AlertHistory, error) {
 	args := m.Called(ctx, alertID, limit, offset)
 	return args.Get(0).([]models.AlertHistory), args.Error(1)
@@ -200,7 +242,7 @@ func TestWorkerIntegration(t *testing.T) {
 	mockAlertRepo := new(MockAlertRepository)
 
 	// Create alert evaluator job
-	job := jobs.NewAlertEvaluatorJob(nil, mockAlertService, mockAlertRepo)
+	job := jobs.NewAlertEvaluatorJob(nil, mockAlertService, mockAlertRepo, nil, nil)
 
 	t.Run("Worker finds and triggers price alert", func(t *testing.T) {
 		userID := uuid.New()
@@ -0,0 +1,133 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/internal/workerrpc"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// taskTimeout bounds how long a worker task may take to report its outcome
+const taskTimeout = 30 * time.Second
+
+// runTask asks the API to run a worker task and waits for the worker's final progress
+// event to come back through the API's websocket
+func runTask(t *testing.T, c *client, stream *realtime, path string) workerrpc.Progress {
+	t.Helper()
+	var accepted workerrpc.TaskAccepted
+	c.call(t, http.MethodPost, path, nil, http.StatusAccepted, &accepted)
+	require.NotEqual(t, uuid.Nil, accepted.TaskID)
+
+	var progress workerrpc.Progress
+	stream.waitFor(t, events.TypeTaskProgress, taskTimeout, func(data json.RawMessage) bool {
+		var p workerrpc.Progress
+		json.Unmarshal(data, &p)
+		if p.TaskID != accepted.TaskID || p.Status == workerrpc.StatusRunning {
+			return false
+		}
+		progress = p
+		return true
+	})
+	return progress
+}
+
+// TestPriceAlertJourney signs in, adds and syncs a wallet, sets a price alert on a token,
+// then moves the token's price across the alert's threshold and follows the trigger
+// from the worker back to the user
+func TestPriceAlertJourney(t *testing.T) {
+	ctx := context.Background()
+
+	// Sign in
+	user := signIn(t)
+	var me struct {
+		Address string `json:"address"`
+	}
+	user.call(t, http.MethodGet, "/api/v1/auth/me", nil, http.StatusOK, &me)
+	assert.Equal(t, user.user.Address, me.Address)
+	stream := user.connectRealtime(t)
+
+	// Add a wallet. The API has no endpoint for it yet, so it's stored as the app would.
+	label := "Main"
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.user.ID, user.address, 1, &label, true)
+	require.NoError(t, err)
+
+	// Sync it on the worker; any address holds a small balance with the fake providers
+	progress := runTask(t, user, stream, fmt.Sprintf("/api/v1/wallets/%s/sync", wallet.ID))
+	assert.Equal(t, workerrpc.StatusCompleted, progress.Status, progress.Error)
+	assert.Equal(t, workerrpc.TaskSyncWallet, progress.Task)
+	assert.Equal(t, wallet.ID, progress.TargetID)
+
+	// A token whose price the test controls; the price jobs don't know it, so nothing
+	// else moves it
+	address := fmt.Sprintf("0x%040x", time.Now().UnixNano())
+	var tokenID uuid.UUID
+	require.NoError(t, db.QueryRow(ctx, `
+		INSERT INTO tokens (address, chain_id, symbol, name, decimals)
+		VALUES ($1, 1, 'E2E', 'End To End', 18) RETURNING id`, address).Scan(&tokenID))
+	prices := repos.NewTokenMetadataRepository(db)
+	_, err = prices.SavePrice(ctx, tokenID, 1.00, 0)
+	require.NoError(t, err)
+
+	// Create an alert for the price rising above 1.50
+	var alert models.Alert
+	user.call(t, http.MethodPost, "/api/v1/alerts/", map[string]interface{}{
+		"type":         models.AlertTypePriceAbove,
+		"target":       map[string]interface{}{"type": "token", "identifier": address, "chainId": 1},
+		"conditions":   map[string]interface{}{"price": 1.50},
+		"notification": map[string]interface{}{"in_app": true},
+	}, http.StatusCreated, &alert)
+	require.NotEqual(t, uuid.Nil, alert.ID)
+
+	// Below the threshold the worker evaluates it without triggering
+	progress = runTask(t, user, stream, fmt.Sprintf("/api/v1/alerts/%s/evaluate", alert.ID))
+	require.Equal(t, workerrpc.StatusCompleted, progress.Status, progress.Error)
+	assert.Equal(t, false, progress.Result["triggered"])
+
+	// The price moves above it
+	_, err = prices.SavePrice(ctx, tokenID, 1.75, 75)
+	require.NoError(t, err)
+
+	// The worker triggers the alert, and its in-app notification reaches the user
+	progress = runTask(t, user, stream, fmt.Sprintf("/api/v1/alerts/%s/evaluate", alert.ID))
+	require.Equal(t, workerrpc.StatusCompleted, progress.Status, progress.Error)
+	assert.Equal(t, true, progress.Result["triggered"])
+
+	var notification models.AlertNotificationMessage
+	stream.waitFor(t, events.TypeAlertTriggered, taskTimeout, func(data json.RawMessage) bool {
+		json.Unmarshal(data, &notification)
+		return notification.AlertID == alert.ID
+	})
+	assert.Equal(t, models.AlertTypePriceAbove, notification.Type)
+	assert.Equal(t, 1.75, notification.TriggeredValue["currentPrice"])
+
+	// The trigger and its delivery are recorded, and the API reports them
+	var history []models.AlertHistory
+	user.call(t, http.MethodGet, "/api/v1/alerts/history?alertId="+alert.ID.String(), nil, http.StatusOK, &history)
+	require.Len(t, history, 1)
+	assert.Equal(t, notification.HistoryID, history[0].ID)
+	assert.True(t, history[0].NotificationSent)
+	assert.Nil(t, history[0].NotificationError)
+
+	var channel, status string
+	require.NoError(t, db.QueryRow(ctx, `
+		SELECT channel, status FROM alert_deliveries WHERE history_id = $1`, history[0].ID).Scan(&channel, &status))
+	assert.Equal(t, models.NotificationChannelInApp, channel)
+	assert.Equal(t, models.DeliveryStatusSent, status)
+
+	// Another user can't see or evaluate the alert
+	other := signIn(t)
+	other.call(t, http.MethodGet, "/api/v1/alerts/"+alert.ID.String(), nil, http.StatusNotFound, nil)
+	other.call(t, http.MethodPost, fmt.Sprintf("/api/v1/alerts/%s/evaluate", alert.ID), nil, http.StatusNotFound, nil)
+}
@@ -0,0 +1,184 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/events"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// client calls the API as a signed-in user, asking for the standard response envelope
+type client struct {
+	token   string
+	user    *models.User
+	address string
+}
+
+// signIn creates a fresh key and signs in with it over SIWE, as a wallet would
+func signIn(t *testing.T) *client {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	anonymous := &client{}
+	var nonce struct {
+		Message string `json:"message"`
+	}
+	anonymous.call(t, http.MethodPost, "/api/v1/auth/siwe/nonce", map[string]interface{}{"address": address, "chainId": 1}, http.StatusOK, &nonce)
+
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(nonce.Message), nonce.Message)))
+	signature, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	signature[64] += 27
+
+	var session struct {
+		Token string       `json:"token"`
+		User  *models.User `json:"user"`
+	}
+	anonymous.call(t, http.MethodPost, "/api/v1/auth/siwe/verify", map[string]string{
+		"message":   nonce.Message,
+		"signature": hexutil.Encode(signature),
+	}, http.StatusOK, &session)
+	require.NotEmpty(t, session.Token)
+	require.NotNil(t, session.User)
+
+	return &client{token: session.Token, user: session.User, address: address}
+}
+
+// call sends a JSON request and checks its status, decoding the envelope's data into
+// out when given
+func (c *client) call(t *testing.T, method, path string, body interface{}, wantStatus int, out interface{}) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, apiURL+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Response-Envelope", "standard")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, wantStatus, resp.StatusCode, "%s %s: %s", method, path, raw)
+
+	if out != nil {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &envelope), "%s %s: %s", method, path, raw)
+		require.NoError(t, json.Unmarshal(envelope.Data, out), "%s %s: %s", method, path, raw)
+	}
+}
+
+// realtime is the user's realtime event stream, read from the API's websocket
+type realtime struct {
+	conn   net.Conn
+	events chan events.Event
+}
+
+// connectRealtime opens the user's websocket and starts reading events from it
+func (c *client) connectRealtime(t *testing.T) *realtime {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", strings.TrimPrefix(apiURL, "http://"), 5*time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	fmt.Fprintf(conn, "GET /api/v1/ws HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\nAuthorization: Bearer %s\r\n\r\n",
+		strings.TrimPrefix(apiURL, "http://"), base64.StdEncoding.EncodeToString(nonce), c.token)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	r := &realtime{conn: conn, events: make(chan events.Event, 64)}
+	go r.read(reader)
+	return r
+}
+
+// read decodes the server's text frames into events until the connection closes.
+// Server frames are unmasked and the feed never fragments them.
+func (r *realtime) read(reader *bufio.Reader) {
+	defer close(r.events)
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(reader, head[:]); err != nil {
+			return
+		}
+		length := uint64(head[1] & 0x7F)
+		switch length {
+		case 126:
+			var n uint16
+			if binary.Read(reader, binary.BigEndian, &n) != nil {
+				return
+			}
+			length = uint64(n)
+		case 127:
+			if binary.Read(reader, binary.BigEndian, &length) != nil {
+				return
+			}
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return
+		}
+
+		if head[0]&0x0F != 0x1 {
+			continue // pings and pongs
+		}
+		var event events.Event
+		if json.Unmarshal(payload, &event) == nil {
+			r.events <- event
+		}
+	}
+}
+
+// waitFor returns the first event of the type that match accepts, failing the test if
+// none arrives within the timeout. Events before it are discarded.
+func (r *realtime) waitFor(t *testing.T, eventType string, timeout time.Duration, match func(data json.RawMessage) bool) json.RawMessage {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case event, ok := <-r.events:
+			require.True(t, ok, "realtime connection closed while waiting for %s", eventType)
+			if event.Type == eventType && match(event.Data) {
+				return event.Data
+			}
+		case <-deadline:
+			require.FailNow(t, "no realtime event", "waited %s for %s", timeout, eventType)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,296 @@
+//go:build e2e
+
+// Package e2e runs user journeys against the built API and worker binaries. TestMain
+// starts a disposable Postgres container and applies db/migrations, then builds and
+// starts cmd/api and cmd/worker as separate processes against it with MOCK_PROVIDERS
+// on, the worker serving on-demand tasks to the API over its RPC address. Tests drive
+// the API over HTTP and its realtime websocket, as the frontend does, and read the
+// database for what the API doesn't expose.
+//
+//	go test -tags e2e ./tests/e2e/...
+//
+// Set TEST_DATABASE_URL to run against an existing, empty database instead of Docker.
+// The processes' logs are kept in a temporary directory, printed when a run fails.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// jwtSecret signs the sessions the API hands out
+const jwtSecret = "e2e-jwt-secret"
+
+// startupTimeout bounds how long the API and worker may take to start listening
+const startupTimeout = time.Minute
+
+var (
+	// db is the migrated database the API and worker run against
+	db *pgxpool.Pool
+	// apiURL is the base URL of the running API
+	apiURL string
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		container, err := postgres.Run(ctx, "postgres:16-alpine",
+			postgres.WithDatabase("defi_dashboard_e2e"),
+			postgres.WithUsername("defi"),
+			postgres.WithPassword("defi"),
+			testcontainers.WithWaitStrategy(
+				wait.ForLog("database system is ready to accept connections").
+					WithOccurrence(2).
+					WithStartupTimeout(time.Minute),
+			),
+		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start postgres: %v\n", err)
+			return 1
+		}
+		defer container.Terminate(ctx)
+
+		databaseURL, err = container.ConnectionString(ctx, "sslmode=disable")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get connection string: %v\n", err)
+			return 1
+		}
+	}
+
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		return 1
+	}
+	defer pool.Close()
+	db = pool
+
+	if err := migrate(ctx, pool); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate: %v\n", err)
+		return 1
+	}
+
+	dir, err := os.MkdirTemp("", "defi-dashboard-e2e-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create work directory: %v\n", err)
+		return 1
+	}
+	if err := build(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build: %v\n", err)
+		return 1
+	}
+
+	apiPort, err := freePort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to pick API port: %v\n", err)
+		return 1
+	}
+	rpcPort, err := freePort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to pick worker RPC port: %v\n", err)
+		return 1
+	}
+	apiURL = fmt.Sprintf("http://127.0.0.1:%d", apiPort)
+
+	env := []string{
+		"DATABASE_URL=" + databaseURL,
+		"JWT_SECRET=" + jwtSecret,
+		"MOCK_PROVIDERS=true",
+		"ALCHEMY_API_KEY=mock",
+		"RATE_LIMIT_PER_MINUTE=1000000",
+		"LOG_LEVEL=debug",
+		fmt.Sprintf("PORT=%d", apiPort),
+		fmt.Sprintf("WORKER_RPC_ADDR=127.0.0.1:%d", rpcPort),
+		fmt.Sprintf("WORKER_RPC_URL=http://127.0.0.1:%d", rpcPort),
+		"WORKER_RPC_TOKEN=e2e-worker-token",
+	}
+	worker, err := start(dir, "worker", env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start worker: %v\n", err)
+		return 1
+	}
+	defer worker.stop()
+	api, err := start(dir, "api", env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start API: %v\n", err)
+		return 1
+	}
+	defer api.stop()
+
+	if err := waitForListener(fmt.Sprintf("127.0.0.1:%d", rpcPort), worker); err != nil {
+		fmt.Fprintf(os.Stderr, "worker didn't start: %v\nlogs: %s\n", err, worker.logPath)
+		return 1
+	}
+	if err := waitForHealth(apiURL+"/health", api); err != nil {
+		fmt.Fprintf(os.Stderr, "API didn't start: %v\nlogs: %s\n", err, api.logPath)
+		return 1
+	}
+
+	code := m.Run()
+	if code != 0 {
+		fmt.Fprintf(os.Stderr, "API logs: %s\nworker logs: %s\n", api.logPath, worker.logPath)
+	} else {
+		os.RemoveAll(dir)
+	}
+	return code
+}
+
+// migrate applies every up migration in order, as golang-migrate does in deployments
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	files, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// Without arguments pgx uses the simple protocol, which runs multi-statement files
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// build compiles the API and worker into dir
+func build(dir string) error {
+	cmd := exec.Command("go", "build", "-o", dir+string(filepath.Separator), "./cmd/api", "./cmd/worker")
+	cmd.Dir = filepath.Join("..", "..")
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w\n%s", err, output.String())
+	}
+	return nil
+}
+
+// freePort returns a local TCP port nothing is listening on
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// process is a started API or worker
+type process struct {
+	cmd     *exec.Cmd
+	logPath string
+	exited  chan struct{}
+}
+
+// start runs a built binary from dir, so no .env or config file of the checkout is read,
+// with its output going to a log file beside it
+func start(dir, name string, env []string) (*process, error) {
+	logPath := filepath.Join(dir, name+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(filepath.Join(dir, name))
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return nil, err
+	}
+
+	p := &process{cmd: cmd, logPath: logPath, exited: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		logFile.Close()
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// stop shuts the process down as a deployment would, killing it if it hangs
+func (p *process) stop() {
+	p.cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-p.exited:
+	case <-time.After(30 * time.Second):
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+}
+
+// running reports whether the process hasn't exited
+func (p *process) running() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// waitForListener waits until something accepts connections on addr
+func waitForListener(addr string, p *process) error {
+	deadline := time.Now().Add(startupTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if !p.running() {
+			return fmt.Errorf("exited")
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// waitForHealth waits until the health check at url answers 200
+func waitForHealth(url string, p *process) error {
+	deadline := time.Now().Add(startupTimeout)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			err = fmt.Errorf("health check returned status %d", resp.StatusCode)
+		}
+		if !p.running() {
+			return fmt.Errorf("exited")
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressPoisoningIsFlaggedOnIngestion(t *testing.T) {
+	ctx := context.Background()
+	backfillRepo := repos.NewWalletBackfillRepository(db)
+	repo := repos.NewAddressPoisoningRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	recipient := randomAddress()
+	lookalike := recipient[:6] + strings.Repeat("f", 32) + recipient[38:]
+	transfer := func(from, to, value string) *models.Transaction {
+		block := int64(10)
+		return &models.Transaction{
+			Hash:        fmt.Sprintf("0x%s%056d", uuid.NewString()[:8], 0),
+			ChainID:     1,
+			FromAddress: from,
+			ToAddress:   &to,
+			Value:       &value,
+			BlockNumber: &block,
+			Timestamp:   time.Now().UTC(),
+			Status:      models.TransactionStatusConfirmed,
+			Type:        "send",
+		}
+	}
+
+	// The wallet pays its recipient, then a lookalike fakes a zero-value send to itself
+	_, err = backfillRepo.SaveTransactions(ctx, wallet, []*models.Transaction{transfer(wallet.Address, recipient, "1000")})
+	require.NoError(t, err)
+	poisoned := transfer(wallet.Address, lookalike, "0")
+	_, err = backfillRepo.SaveTransactions(ctx, wallet, []*models.Transaction{poisoned, transfer(recipient, wallet.Address, "0")})
+	require.NoError(t, err)
+
+	counterparties, err := repo.GetByWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.Len(t, counterparties, 1)
+	assert.Equal(t, lookalike, counterparties[0].Address)
+	assert.Equal(t, recipient, counterparties[0].LookalikeOf)
+	assert.Equal(t, 1, counterparties[0].TransactionCount)
+
+	found, err := repos.NewTransactionRepository(db).Search(ctx, user.ID, repos.TransactionSearchFilters{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	for _, tx := range found {
+		if tx.Hash == poisoned.Hash {
+			require.NotNil(t, tx.PoisoningLookalikeOf)
+			assert.Equal(t, recipient, *tx.PoisoningLookalikeOf)
+		} else {
+			assert.Nil(t, tx.PoisoningLookalikeOf)
+		}
+	}
+
+	// Storing the transfer again doesn't count it twice
+	_, err = backfillRepo.SaveTransactions(ctx, wallet, []*models.Transaction{poisoned})
+	require.NoError(t, err)
+	counterparties, err = repo.GetByWallet(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counterparties[0].TransactionCount)
+}
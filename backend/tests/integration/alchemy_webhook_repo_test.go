@@ -0,0 +1,134 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlchemyWebhookRepositoryEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewAlchemyWebhookRepository(db)
+	block := int64(100)
+
+	newEvent := func() *models.AlchemyWebhookEvent {
+		return &models.AlchemyWebhookEvent{
+			EventID:   "whevt_" + uuid.NewString(),
+			WebhookID: "wh_test",
+			Type:      "ADDRESS_ACTIVITY",
+			ChainID:   1,
+			Addresses: []string{"0x8ba1f109551bd432803012645ac136ddd64dba72"},
+			FromBlock: &block,
+			ToBlock:   &block,
+		}
+	}
+	event := newEvent()
+	queued, err := repo.InsertEvent(ctx, event)
+	require.NoError(t, err)
+	assert.True(t, queued)
+	assert.Equal(t, models.AlchemyWebhookEventPending, event.Status)
+
+	// A redelivery isn't queued again
+	redelivered := *event
+	queued, err = repo.InsertEvent(ctx, &redelivered)
+	require.NoError(t, err)
+	assert.False(t, queued)
+
+	failing := newEvent()
+	_, err = repo.InsertEvent(ctx, failing)
+	require.NoError(t, err)
+
+	pending, err := repo.GetPending(ctx, 1000)
+	require.NoError(t, err)
+	ids := map[uuid.UUID]*models.AlchemyWebhookEvent{}
+	for _, e := range pending {
+		ids[e.ID] = e
+	}
+	require.Contains(t, ids, event.ID)
+	assert.Equal(t, event.Addresses, ids[event.ID].Addresses)
+	assert.Equal(t, block, *ids[event.ID].FromBlock)
+
+	require.NoError(t, repo.MarkProcessed(ctx, event.ID))
+	require.NoError(t, repo.RecordError(ctx, failing.ID, "boom", 2))
+	require.NoError(t, repo.RecordError(ctx, failing.ID, "boom", 2))
+
+	pending, err = repo.GetPending(ctx, 1000)
+	require.NoError(t, err)
+	for _, e := range pending {
+		assert.NotEqual(t, event.ID, e.ID, "processed events aren't pending")
+		assert.NotEqual(t, failing.ID, e.ID, "events failing too often aren't pending")
+	}
+
+	pruned, err := repo.DeleteBefore(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, pruned, int64(2))
+}
+
+func TestAlchemyWebhookRepositoryAddresses(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewAlchemyWebhookRepository(db)
+	user := newUser(t)
+	_, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	watched, err := repo.GetWatchedAddresses(ctx, 1)
+	require.NoError(t, err)
+	assert.Contains(t, watched, strings.ToLower(user.Address))
+
+	webhookID := "wh_" + uuid.NewString()
+	require.NoError(t, repo.SetSubscribed(ctx, webhookID, 1, []string{"0xa", "0xb"}, nil))
+	require.NoError(t, repo.SetSubscribed(ctx, webhookID, 1, []string{"0xc"}, []string{"0xa"}))
+
+	subscribed, err := repo.GetSubscribedAddresses(ctx, webhookID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xb", "0xc"}, subscribed)
+}
+
+func TestWalletBackfillRepositorySaveTransactions(t *testing.T) {
+	ctx := context.Background()
+	user := newUser(t)
+	walletRepo := repos.NewWalletRepository(db)
+	wallet, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	// Addresses match whatever their case, on the given chain only
+	found, err := walletRepo.GetAllByAddresses(ctx, []string{"0x" + strings.ToUpper(wallet.Address[2:])}, 1)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, wallet.ID, found[0].ID)
+	found, err = walletRepo.GetAllByAddresses(ctx, []string{wallet.Address}, 137)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	block := int64(42)
+	value := "1000000000000000000"
+	tx := &models.Transaction{
+		Hash:        "0x" + uuid.NewString()[:8] + "00000000000000000000000000000000000000000000000000000000",
+		ChainID:     1,
+		FromAddress: "0x9999999999999999999999999999999999999999",
+		ToAddress:   &wallet.Address,
+		Value:       &value,
+		BlockNumber: &block,
+		Timestamp:   time.Now().UTC(),
+		Status:      models.TransactionStatusConfirmed,
+		Type:        "receive",
+	}
+	repo := repos.NewWalletBackfillRepository(db)
+	linked, err := repo.SaveTransactions(ctx, wallet, []*models.Transaction{tx})
+	require.NoError(t, err)
+	assert.Equal(t, 1, linked)
+
+	// Reported again, e.g. by a redelivered webhook, it's already linked
+	linked, err = repo.SaveTransactions(ctx, wallet, []*models.Transaction{tx})
+	require.NoError(t, err)
+	assert.Zero(t, linked)
+}
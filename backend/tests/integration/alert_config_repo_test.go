@@ -0,0 +1,53 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertConfigRepository(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := repos.NewAlertRepository(db, nil)
+	repo := repos.NewAlertConfigRepository(db)
+	user := newUser(t)
+
+	price := 1850.5
+	newAlert := func() *models.Alert {
+		alert := &models.Alert{
+			ID:         uuid.New(),
+			UserID:     user.ID,
+			Type:       models.AlertTypePriceBelow,
+			Status:     models.AlertStatusActive,
+			Target:     models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+			Conditions: models.AlertConditions{Price: &price},
+		}
+		require.NoError(t, alertRepo.Create(ctx, alert))
+		return alert
+	}
+	old, replacement := newAlert(), newAlert()
+
+	require.NoError(t, repo.SetKey(ctx, user.ID, old.ID, "eth/below"))
+	keys, err := repo.GetKeys(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uuid.UUID{"eth/below": old.ID}, keys)
+
+	// The key moves to the replacement
+	require.NoError(t, repo.SetKey(ctx, user.ID, replacement.ID, "eth/below"))
+	keys, err = repo.GetKeys(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uuid.UUID{"eth/below": replacement.ID}, keys)
+
+	// Deleting the alert drops its key
+	require.NoError(t, alertRepo.Delete(ctx, replacement.ID))
+	keys, err = repo.GetKeys(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
\ No newline at end of file
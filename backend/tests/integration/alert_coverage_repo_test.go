@@ -0,0 +1,65 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertCoverageRepository(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := repos.NewAlertRepository(db, nil)
+	repo := repos.NewAlertCoverageRepository(db)
+	user := newUser(t)
+
+	price := 1850.5
+	alert := &models.Alert{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Type:       models.AlertTypePriceBelow,
+		Status:     models.AlertStatusActive,
+		Target:     models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Conditions: models.AlertConditions{Price: &price},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	hour := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	dataAt := hour.Add(2 * time.Minute)
+	require.NoError(t, repo.RecordChecks(ctx, []models.AlertCheck{
+		{AlertID: alert.ID, CheckedAt: hour.Add(5 * time.Minute), DataAt: &dataAt},
+		{AlertID: alert.ID, CheckedAt: hour.Add(10 * time.Minute), Stale: true},
+		// Checks of deleted alerts are dropped rather than failing the batch
+		{AlertID: uuid.New(), CheckedAt: hour},
+	}))
+	require.NoError(t, repo.RecordChecks(ctx, []models.AlertCheck{
+		{AlertID: alert.ID, CheckedAt: hour.Add(15 * time.Minute), Stale: true, Failed: true},
+		{AlertID: alert.ID, CheckedAt: hour.Add(70 * time.Minute)},
+	}))
+
+	buckets, err := repo.GetBuckets(ctx, alert.ID, hour, hour.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, buckets, 2)
+	assert.True(t, buckets[0].Bucket.Equal(hour))
+	assert.Equal(t, 3, buckets[0].Checks)
+	assert.Equal(t, 1, buckets[0].StaleChecks, "failed checks aren't also stale")
+	assert.Equal(t, 1, buckets[0].FailedChecks)
+	assert.True(t, buckets[0].LastCheckedAt.Equal(hour.Add(15*time.Minute)))
+	require.NotNil(t, buckets[0].LastDataAt)
+	assert.True(t, buckets[0].LastDataAt.Equal(dataAt))
+	assert.Equal(t, 1, buckets[1].Checks)
+
+	pruned, err := repo.DeleteBefore(ctx, hour.Add(time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, pruned, int64(1))
+	buckets, err = repo.GetBuckets(ctx, alert.ID, hour, hour.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, buckets, 1)
+}
@@ -0,0 +1,115 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewAlertRepository(db, nil)
+	user := newUser(t)
+
+	price := 1850.5
+	threshold := "5000000000000000000"
+	alert := &models.Alert{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Type:   models.AlertTypePriceBelow,
+		Status: models.AlertStatusActive,
+		Target: models.AlertTarget{
+			Type:       "token",
+			Identifier: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+			ChainID:    1,
+		},
+		Conditions:   models.AlertConditions{Price: &price, Threshold: &threshold},
+		Notification: models.AlertNotification{Email: true, Webhook: "https://example.com/hook"},
+	}
+	require.NoError(t, repo.Create(ctx, alert))
+	assert.False(t, alert.CreatedAt.IsZero())
+
+	got, err := repo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alert.Target, got.Target)
+	assert.Equal(t, alert.Conditions, got.Conditions)
+	assert.Equal(t, alert.Notification, got.Notification)
+	assert.Zero(t, got.TriggerCount)
+
+	minAPR := 4.0
+	got.Status = models.AlertStatusDisabled
+	got.Conditions = models.AlertConditions{MinAPR: &minAPR}
+	got.Notification = models.AlertNotification{Email: false}
+	require.NoError(t, repo.Update(ctx, got))
+	assert.Equal(t, &minAPR, got.Conditions.MinAPR)
+	assert.Nil(t, got.Conditions.Price)
+	assert.Empty(t, got.Notification.Webhook)
+
+	disabled := models.AlertStatusDisabled
+	alerts, err := repo.GetByUserID(ctx, user.ID, &disabled, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, alert.ID, alerts[0].ID)
+
+	active := models.AlertStatusActive
+	alerts, err = repo.GetByUserID(ctx, user.ID, &active, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	mutedUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.SetMutedUntil(ctx, alert.ID, &mutedUntil))
+	require.NoError(t, repo.UpdateTriggered(ctx, alert.ID))
+	got, err = repo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.MutedUntil)
+	assert.True(t, mutedUntil.Equal(*got.MutedUntil))
+	assert.Equal(t, 1, got.TriggerCount)
+	assert.NotNil(t, got.LastTriggeredAt)
+
+	history := &models.AlertHistory{
+		ID:                 uuid.New(),
+		AlertID:            alert.ID,
+		TriggeredAt:        time.Now().UTC().Truncate(time.Microsecond),
+		ConditionsSnapshot: models.AlertConditions{Price: &price},
+		TriggeredValue:     map[string]interface{}{"price": 1849.25, "symbol": "WETH"},
+		NotificationSent:   true,
+	}
+	require.NoError(t, repo.CreateHistory(ctx, history))
+	entries, err := repo.GetHistory(ctx, &alert.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, history.ConditionsSnapshot, entries[0].ConditionsSnapshot)
+	assert.Equal(t, history.TriggeredValue, entries[0].TriggeredValue)
+	assert.True(t, history.TriggeredAt.Equal(entries[0].TriggeredAt))
+
+	require.NoError(t, repo.Delete(ctx, alert.ID))
+	_, err = repo.GetByID(ctx, alert.ID)
+	assert.Error(t, err)
+	assert.Error(t, repo.Delete(ctx, alert.ID))
+}
+
+func TestAlertRepositoryReadsFixtures(t *testing.T) {
+	repo := repos.NewAlertRepository(db, nil)
+
+	for _, expected := range seed.Alerts {
+		got, err := repo.GetByID(context.Background(), expected.ID)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Type, got.Type)
+		assert.Equal(t, expected.Target, got.Target)
+		assert.Equal(t, expected.Conditions, got.Conditions)
+		assert.Equal(t, expected.Notification, got.Notification)
+	}
+
+	alerts, err := repo.GetByUserID(context.Background(), fixtures.ID("user:alice"), nil, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, alerts, 2)
+}
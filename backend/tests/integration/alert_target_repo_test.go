@@ -0,0 +1,97 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertTargetRepositoryExpiresAndRepoints(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewAlertTargetRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+	user := newUser(t)
+	suffix := uuid.NewString()
+
+	// The old pool was deactivated for a new version of the same pool
+	oldPool, newPool := "old-"+suffix, "new-"+suffix
+	for _, pool := range []struct {
+		id     string
+		active bool
+	}{{oldPool, false}, {newPool, true}} {
+		_, err := db.Exec(ctx, `
+			INSERT INTO yield_pools (pool_id, protocol, pool_name, chain, symbol, tvl_usd, is_active)
+			VALUES ($1, 'aave-v3', 'USDC Supply', 'Ethereum', 'USDC', 1000000, $2)`, pool.id, pool.active)
+		require.NoError(t, err)
+	}
+
+	minAPR := 4.0
+	alert := &models.Alert{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		Type:       models.AlertTypeAPRChange,
+		Status:     models.AlertStatusActive,
+		Target:     models.AlertTarget{Type: "pool", Identifier: oldPool},
+		Conditions: models.AlertConditions{MinAPR: &minAPR},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	staleBefore := time.Now().Add(-time.Hour)
+	gone, err := repo.GetGone(ctx, staleBefore, 1000)
+	require.NoError(t, err)
+	var found *models.GoneAlertTarget
+	for _, g := range gone {
+		if g.AlertID == alert.ID {
+			found = g
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, models.AlertExpiredPoolDeactivated, found.Reason)
+
+	outboxID, ok, err := repo.Expire(ctx, alert.ID, found.Reason, time.Now())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotEqual(t, uuid.Nil, outboxID)
+	_, ok, err = repo.Expire(ctx, alert.ID, found.Reason, time.Now())
+	require.NoError(t, err)
+	assert.False(t, ok, "an alert is expired, and its owner told, once")
+
+	var queued int
+	require.NoError(t, db.QueryRow(ctx, `SELECT COUNT(*) FROM notification_outbox WHERE alert_id = $1`, alert.ID).Scan(&queued))
+	assert.Equal(t, 1, queued)
+
+	expired, err := alertRepo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertStatusExpired, expired.Status)
+	require.NotNil(t, expired.ExpiredReason)
+	assert.Equal(t, models.AlertExpiredPoolDeactivated, *expired.ExpiredReason)
+	assert.NotNil(t, expired.ExpiredAt)
+
+	successors, err := repo.GetSuccessors(ctx, alert.Target, staleBefore)
+	require.NoError(t, err)
+	require.Len(t, successors, 1)
+	assert.Equal(t, newPool, successors[0].Target.Identifier)
+
+	live, err := repo.IsLive(ctx, models.AlertTarget{Type: "pool", Identifier: oldPool}, staleBefore)
+	require.NoError(t, err)
+	assert.False(t, live)
+	live, err = repo.IsLive(ctx, successors[0].Target, staleBefore)
+	require.NoError(t, err)
+	assert.True(t, live)
+
+	require.NoError(t, repo.Repoint(ctx, alert.ID, successors[0].Target))
+	repointed, err := alertRepo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertStatusActive, repointed.Status)
+	assert.Equal(t, newPool, repointed.Target.Identifier)
+	assert.Nil(t, repointed.ExpiredReason)
+	assert.Error(t, repo.Repoint(ctx, alert.ID, successors[0].Target), "only expired alerts are re-pointed")
+}
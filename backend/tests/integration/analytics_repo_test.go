@@ -0,0 +1,41 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewAnalyticsRepository(db)
+	user, other := newUser(t), newUser(t)
+
+	optedOutAt, err := repo.GetOptOut(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, optedOutAt)
+
+	require.NoError(t, repo.SetOptOut(ctx, user.ID, true))
+	optedOutAt, err = repo.GetOptOut(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, optedOutAt)
+	require.NoError(t, repo.SetOptOut(ctx, user.ID, true))
+	again, err := repo.GetOptOut(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *optedOutAt, *again, "opting out again keeps the original time")
+
+	optedOut, err := repo.OptedOut(ctx, []uuid.UUID{user.ID, other.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]bool{user.ID: true}, optedOut)
+
+	require.NoError(t, repo.SetOptOut(ctx, user.ID, false))
+	optedOut, err = repo.OptedOut(ctx, []uuid.UUID{user.ID, other.ID})
+	require.NoError(t, err)
+	assert.Empty(t, optedOut)
+}
\ No newline at end of file
@@ -0,0 +1,54 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewAuditRepository(db)
+	admin := newUser(t)
+	user := newUser(t)
+
+	session := &models.ImpersonationSession{AdminID: admin.ID, UserID: user.ID, Reason: "ticket 42", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.CreateImpersonationSession(ctx, session))
+
+	got, err := repo.GetImpersonationSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "ticket 42", got.Reason)
+	assert.Nil(t, got.EndedAt)
+
+	method, path := "GET", "/api/v1/portfolio"
+	require.NoError(t, repo.Record(ctx, &models.AuditLogEntry{ActorID: admin.ID, Action: models.AuditActionImpersonationStarted, TargetUserID: &user.ID, SessionID: &session.ID}))
+	require.NoError(t, repo.Record(ctx, &models.AuditLogEntry{ActorID: admin.ID, Action: models.AuditActionImpersonatedRequest, TargetUserID: &user.ID, SessionID: &session.ID, Method: &method, Path: &path}))
+
+	entries, err := repo.List(ctx, repos.AuditLogFilter{SessionID: &session.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditActionImpersonatedRequest, entries[0].Action, "newest first")
+	assert.Equal(t, path, *entries[0].Path)
+
+	entries, err = repo.List(ctx, repos.AuditLogFilter{TargetUserID: &admin.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	ended, err := repo.EndImpersonationSession(ctx, session.ID, user.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, ended, "only the admin who opened a session can end it")
+	ended, err = repo.EndImpersonationSession(ctx, session.ID, admin.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, ended)
+	ended, err = repo.EndImpersonationSession(ctx, session.ID, admin.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, ended)
+}
@@ -0,0 +1,173 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceRepositoryReplaceWalletBalances(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewBalanceRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	usdcValue := 2500.0
+	usdc := &models.Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC", Name: "USD Coin", Decimals: 6}
+	link := &models.Token{Address: "0x514910771af9ca656af840dff83e8264ecf986ca", Symbol: "LINK", Name: "Chainlink", Decimals: 18}
+	before := time.Now().Add(-time.Second)
+	changes, err := repo.ReplaceWalletBalances(ctx, wallet.ID, 1, []*models.Balance{
+		{Token: usdc, Balance: "2500000000", BalanceUSD: &usdcValue},
+		{Token: link, Balance: "12500000000000000000"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, changes, 2, "new tokens are changes from zero")
+
+	changes, err = repo.ReplaceWalletBalances(ctx, wallet.ID, 1, []*models.Balance{
+		{Token: usdc, Balance: "2500000000", BalanceUSD: &usdcValue},
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 1, "unchanged balances aren't recorded")
+	assert.Equal(t, "LINK", changes[0].Token.Symbol)
+	assert.Equal(t, "-12500000000000000000", changes[0].Delta)
+
+	recorded, err := repo.GetChangesSince(ctx, wallet.Address, 0, before)
+	require.NoError(t, err)
+	require.Len(t, recorded, 3)
+	assert.Equal(t, wallet.ID, recorded[0].WalletID)
+	other, err := repo.GetChangesSince(ctx, wallet.Address, 137, before)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	_, err = repo.ReplaceWalletBalances(ctx, wallet.ID, 1, []*models.Balance{
+		{Token: usdc, Balance: "1000000"},
+	})
+	require.NoError(t, err)
+
+	rows, err := db.Query(ctx, `SELECT t.address, b.balance::text FROM balances b JOIN tokens t ON t.id = b.token_id WHERE b.wallet_id = $1`, wallet.ID)
+	require.NoError(t, err)
+	defer rows.Close()
+	held := map[string]string{}
+	for rows.Next() {
+		var address, balance string
+		require.NoError(t, rows.Scan(&address, &balance))
+		held[address] = balance
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, map[string]string{"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48": "1000000"}, held, "tokens no longer held are removed")
+}
+
+func TestBalanceRepositoryStoresBlocks(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewBalanceRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	usdc := &models.Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC", Name: "USD Coin", Decimals: 6}
+	link := &models.Token{Address: "0x514910771af9ca656af840dff83e8264ecf986ca", Symbol: "LINK", Name: "Chainlink", Decimals: 18}
+	stamp := func(number int64, hash string, balances ...*models.Balance) []*models.Balance {
+		for _, b := range balances {
+			b.BlockNumber, b.BlockHash = &number, &hash
+		}
+		return balances
+	}
+
+	_, err = repo.ReplaceWalletBalances(ctx, wallet.ID, 1, stamp(100, "0xaa",
+		&models.Balance{Token: usdc, Balance: "2500000000"},
+		&models.Balance{Token: link, Balance: "1"},
+	))
+	require.NoError(t, err)
+	_, err = repo.ReplaceWalletBalances(ctx, wallet.ID, 1, stamp(101, "0xbb",
+		&models.Balance{Token: usdc, Balance: "1000000"},
+	))
+	require.NoError(t, err)
+
+	var number int64
+	var hash string
+	require.NoError(t, db.QueryRow(ctx, `SELECT block_number, block_hash FROM balances WHERE wallet_id = $1`, wallet.ID).Scan(&number, &hash))
+	assert.Equal(t, int64(101), number)
+	assert.Equal(t, "0xbb", hash)
+
+	// Every change is kept in the history with the block it was read at, including
+	// the token no longer held
+	rows, err := db.Query(ctx, `
+		SELECT balance::text, block_number, block_hash FROM balance_history
+		WHERE wallet_id = $1 ORDER BY block_number, balance`, wallet.ID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var history []string
+	for rows.Next() {
+		var balance string
+		require.NoError(t, rows.Scan(&balance, &number, &hash))
+		history = append(history, fmt.Sprintf("%s@%d/%s", balance, number, hash))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"1@100/0xaa", "2500000000@100/0xaa", "0@101/0xbb", "1000000@101/0xbb"}, history)
+}
+
+func TestBalanceRefreshRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewBalanceRefreshRepository(db)
+	walletRepo := repos.NewWalletRepository(db)
+	user := newUser(t)
+	mainnet, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	polygon, err := walletRepo.Create(ctx, user.ID, user.Address, 137, nil, false)
+	require.NoError(t, err)
+
+	none, err := repo.GetByID(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	chainID := 1
+	refresh := &models.BalanceRefresh{RequestedBy: &user.ID, WalletIDs: []uuid.UUID{mainnet.ID, polygon.ID}, ChainID: &chainID}
+	require.NoError(t, repo.Create(ctx, refresh))
+	assert.Equal(t, models.BalanceRefreshPending, refresh.Status)
+	assert.Equal(t, 1, refresh.Total, "only wallets on the chain are counted")
+
+	all := &models.BalanceRefresh{WalletIDs: []uuid.UUID{mainnet.ID, polygon.ID}}
+	require.NoError(t, repo.Create(ctx, all))
+	assert.Equal(t, 2, all.Total)
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.Start(ctx, all, now))
+	assert.Equal(t, models.BalanceRefreshRunning, all.Status)
+	require.NotNil(t, all.StartedAt)
+
+	wallets, err := repo.NextWallets(ctx, all, 1)
+	require.NoError(t, err)
+	require.Len(t, wallets, 1)
+	all.Cursor = &wallets[0].ID
+	all.Refreshed = 1
+	require.NoError(t, repo.SaveProgress(ctx, all, false, now))
+	assert.Equal(t, 1, all.Refreshed)
+
+	rest, err := repo.NextWallets(ctx, all, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 1, "the next wallets start after the cursor")
+	assert.NotEqual(t, wallets[0].ID, rest[0].ID)
+
+	require.NoError(t, repo.SaveProgress(ctx, all, true, now))
+	assert.Equal(t, models.BalanceRefreshCompleted, all.Status)
+	require.NotNil(t, all.CompletedAt)
+
+	listed, err := repo.List(ctx, 10)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, 0, len(listed))
+	for _, r := range listed {
+		ids = append(ids, r.ID)
+	}
+	assert.Contains(t, ids, refresh.ID)
+	assert.Contains(t, ids, all.ID)
+}
@@ -0,0 +1,66 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewBillingRepository(db)
+	user := newUser(t)
+	customerID := "cus_" + user.ID.String()
+
+	account, err := repo.GetAccount(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, account)
+
+	graceUntil := time.Now().Add(-time.Minute).UTC().Truncate(time.Microsecond)
+	require.NoError(t, repo.SaveAccount(ctx, &models.BillingAccount{
+		UserID:           user.ID,
+		StripeCustomerID: customerID,
+		Status:           models.SubscriptionPastDue,
+		GraceUntil:       &graceUntil,
+	}))
+	account, err = repo.GetAccountByCustomer(ctx, customerID)
+	require.NoError(t, err)
+	require.NotNil(t, account)
+	assert.Equal(t, user.ID, account.UserID)
+	assert.Equal(t, models.SubscriptionPastDue, account.Status)
+
+	expired, err := repo.GetExpiredGrace(ctx, time.Now())
+	require.NoError(t, err)
+	var found bool
+	for _, expiredAccount := range expired {
+		found = found || expiredAccount.UserID == user.ID
+	}
+	assert.True(t, found)
+
+	invoice := &models.BillingInvoice{ID: "in_" + user.ID.String(), UserID: user.ID, Status: "open", AmountDue: 900, Currency: "usd", CreatedAt: time.Now()}
+	require.NoError(t, repo.SaveInvoice(ctx, invoice))
+	invoice.Status, invoice.AmountPaid = "paid", 900
+	require.NoError(t, repo.SaveInvoice(ctx, invoice))
+	invoices, err := repo.ListInvoices(ctx, user.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, invoices, 1)
+	assert.Equal(t, "paid", invoices[0].Status)
+	assert.Equal(t, int64(900), invoices[0].AmountPaid)
+
+	eventID := "evt_" + user.ID.String()
+	handled, err := repo.EventHandled(ctx, eventID)
+	require.NoError(t, err)
+	assert.False(t, handled)
+	require.NoError(t, repo.RecordEvent(ctx, eventID, "invoice.paid"))
+	require.NoError(t, repo.RecordEvent(ctx, eventID, "invoice.paid"))
+	handled, err = repo.EventHandled(ctx, eventID)
+	require.NoError(t, err)
+	assert.True(t, handled)
+}
\ No newline at end of file
@@ -0,0 +1,53 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewBudgetRepository(db)
+	user := newUser(t)
+
+	none, err := repo.Get(ctx, user.ID, models.BudgetMetricGasSpend)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	budget := &models.Budget{UserID: user.ID, Metric: models.BudgetMetricGasSpend, LimitUSD: 100}
+	require.NoError(t, repo.Upsert(ctx, budget))
+	assert.NotEqual(t, uuid.Nil, budget.ID)
+
+	// Setting the metric's budget again replaces its limit
+	again := &models.Budget{UserID: user.ID, Metric: models.BudgetMetricGasSpend, LimitUSD: 250}
+	require.NoError(t, repo.Upsert(ctx, again))
+	assert.Equal(t, budget.ID, again.ID)
+	assert.Equal(t, 250.0, again.LimitUSD)
+
+	require.NoError(t, repo.Upsert(ctx, &models.Budget{UserID: user.ID, Metric: models.BudgetMetricRealizedLoss, LimitUSD: 1000}))
+	budgets, err := repo.GetByUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, budgets, 2)
+	assert.Equal(t, models.BudgetMetricGasSpend, budgets[0].Metric)
+	assert.Equal(t, models.BudgetMetricRealizedLoss, budgets[1].Metric)
+
+	volume, err := repo.GetTradingVolume(ctx, user.ID, time.Now().AddDate(0, -1, 0), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, volume)
+
+	deleted, err := repo.Delete(ctx, user.ID, models.BudgetMetricGasSpend)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = repo.Delete(ctx, user.ID, models.BudgetMetricGasSpend)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
@@ -0,0 +1,79 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplianceRepositoryScreensCounterparties(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewComplianceRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	sanctioned := randomAddress()
+	list := &models.ScreeningList{Name: "sanctions-" + uuid.NewString(), Category: models.ScreeningCategorySanctions}
+	require.NoError(t, repo.CreateList(ctx, list, []string{sanctioned}))
+	assert.Equal(t, repos.ErrScreeningListExists, repo.CreateList(ctx, &models.ScreeningList{Name: list.Name, Category: list.Category}, nil))
+
+	value := "1000"
+	block := int64(10)
+	_, err = repos.NewWalletBackfillRepository(db).SaveTransactions(ctx, wallet, []*models.Transaction{{
+		Hash:        fmt.Sprintf("0x%s%056d", uuid.NewString()[:8], 0),
+		ChainID:     1,
+		FromAddress: wallet.Address,
+		ToAddress:   &sanctioned,
+		Value:       &value,
+		BlockNumber: &block,
+		Timestamp:   time.Now().UTC(),
+		Status:      models.TransactionStatusConfirmed,
+		Type:        "send",
+	}})
+	require.NoError(t, err)
+
+	hits, err := repo.GetHits(ctx)
+	require.NoError(t, err)
+	var found []models.ExposureHit
+	for _, h := range hits {
+		if h.WalletID == wallet.ID {
+			found = append(found, *h)
+		}
+	}
+	require.Len(t, found, 1)
+	assert.Equal(t, sanctioned, found[0].Address)
+	assert.Equal(t, models.ExposureSent, found[0].Direction)
+	assert.Equal(t, 1, found[0].TxCount)
+
+	at := time.Now().UTC()
+	require.NoError(t, repo.SaveExposures(ctx, []*models.WalletExposure{{WalletID: wallet.ID, Level: models.ExposureHigh, Hits: found}}, at))
+	exposures, err := repo.GetByUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, exposures, 1)
+	assert.Equal(t, models.ExposureHigh, exposures[0].Level)
+	require.Len(t, exposures[0].Hits, 1)
+	assert.Equal(t, sanctioned, exposures[0].Hits[0].Address)
+
+	// Emptying the list clears the wallet's exposure on the next screening
+	ok, err := repo.SetAddresses(ctx, list.ID, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, repo.SaveExposures(ctx, nil, at.Add(time.Minute)))
+	exposures, err = repo.GetByUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, exposures)
+
+	ok, err = repo.DeleteList(ctx, list.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,95 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomAddress returns an EVM address no fixture uses
+func randomAddress() string {
+	id := uuid.New()
+	return "0x" + hex.EncodeToString(id[:]) + "00000000"
+}
+
+func TestContractAndHolderRepositoriesFollowHeldTokens(t *testing.T) {
+	ctx := context.Background()
+	contractRepo := repos.NewContractRepository(db)
+	holderRepo := repos.NewTokenHolderRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	tokenAddress := randomAddress()
+	_, err = repos.NewBalanceRepository(db).ReplaceWalletBalances(ctx, wallet.ID, 1, []*models.Balance{
+		{Token: &models.Token{Address: tokenAddress, Symbol: "SMOL", Name: "Small Cap", Decimals: 18}, Balance: "2500"},
+	})
+	require.NoError(t, err)
+	var tokenID uuid.UUID
+	require.NoError(t, db.QueryRow(ctx, `SELECT id FROM tokens WHERE LOWER(address) = $1 AND chain_id = 1`, tokenAddress).Scan(&tokenID))
+
+	// The held token needs its contract and holders looked up
+	stale, err := contractRepo.GetStale(ctx, time.Hour, 100000)
+	require.NoError(t, err)
+	assert.Contains(t, stale, models.ContractRef{ChainID: 1, Address: tokenAddress})
+	staleTokens, err := holderRepo.GetStaleTokens(ctx, time.Hour, 100000)
+	require.NoError(t, err)
+	var found bool
+	for _, token := range staleTokens {
+		found = found || token.ID == tokenID
+	}
+	assert.True(t, found)
+
+	name, implementation, verified := "SmolToken", randomAddress(), false
+	deployedAt := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+	info := &models.ContractInfo{
+		ChainID: 1, Address: strings.ToUpper(tokenAddress[:2]) + tokenAddress[2:], IsContract: true, Verified: true,
+		Name: &name, IsProxy: true, Implementation: &implementation, ImplementationVerified: &verified,
+		DeployedAt: &deployedAt, Source: "etherscan",
+	}
+	require.NoError(t, contractRepo.Upsert(ctx, info))
+	contracts, err := contractRepo.GetMany(ctx, 1, []string{strings.ToUpper(tokenAddress)})
+	require.NoError(t, err)
+	got := contracts[tokenAddress]
+	require.NotNil(t, got, "addresses are stored and looked up lowercase")
+	assert.True(t, got.IsProxy)
+	assert.Equal(t, &implementation, got.Implementation)
+	assert.Equal(t, &verified, got.ImplementationVerified)
+	assert.True(t, deployedAt.Equal(*got.DeployedAt))
+
+	stale, err = contractRepo.GetStale(ctx, time.Hour, 100000)
+	require.NoError(t, err)
+	assert.NotContains(t, stale, models.ContractRef{ChainID: 1, Address: tokenAddress})
+
+	// Holder stats, and the user's share of the supply
+	stats := &models.TokenHolderStats{
+		TokenID: tokenID, TotalSupply: "1000000", Top10SharePct: 82.5, Source: "etherscan",
+		TopHolders: []models.TokenHolderShare{{Address: randomAddress(), Balance: "825000", SharePct: 82.5}},
+	}
+	require.NoError(t, holderRepo.Upsert(ctx, stats))
+	stored, err := holderRepo.Get(ctx, tokenID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "1000000", stored.TotalSupply)
+	assert.Equal(t, stats.TopHolders, stored.TopHolders)
+	assert.InDelta(t, 82.5, stored.Top10SharePct, 0.0001)
+
+	balance, err := holderRepo.GetUserBalance(ctx, user.ID, tokenID)
+	require.NoError(t, err)
+	assert.Equal(t, "2500", balance)
+
+	require.NoError(t, holderRepo.Upsert(ctx, &models.TokenHolderStats{TokenID: tokenID, Source: "etherscan", NotFound: true}))
+	stored, err = holderRepo.Get(ctx, tokenID)
+	require.NoError(t, err)
+	assert.Nil(t, stored, "tokens the explorer has nothing on have no stats")
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewEmailRepository(db)
+	address := uuid.NewString() + "@Example.com"
+
+	messageID := uuid.NewString()
+	delivery := &models.EmailDelivery{Template: "alert", To: address, Subject: "Alert", Provider: "ses", ProviderMessageID: &messageID, Status: models.EmailStatusSent, Attempts: 1}
+	require.NoError(t, repo.RecordDelivery(ctx, delivery))
+
+	updated, err := repo.UpdateDeliveryStatus(ctx, "ses", messageID, models.EmailStatusComplained, nil)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	updated, err = repo.UpdateDeliveryStatus(ctx, "ses", messageID, models.EmailStatusDelivered, nil)
+	require.NoError(t, err)
+	assert.False(t, updated, "complaints are final")
+
+	deliveries, err := repo.GetDeliveries(ctx, address, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.EmailStatusComplained, deliveries[0].Status)
+
+	suppressed, err := repo.IsSuppressed(ctx, address)
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+
+	added, err := repo.Suppress(ctx, &models.EmailSuppression{Email: address, Reason: models.EmailSuppressionHardBounce})
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = repo.Suppress(ctx, &models.EmailSuppression{Email: address, Reason: models.EmailSuppressionComplaint})
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	suppressed, err = repo.IsSuppressed(ctx, address)
+	require.NoError(t, err)
+	assert.True(t, suppressed, "addresses match whatever their case")
+
+	removed, err := repo.Unsuppress(ctx, address)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = repo.Unsuppress(ctx, address)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestEmailRepositoryDisableEmailNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewEmailRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+	user := newUser(t)
+	address := uuid.NewString() + "@example.com"
+	_, err := repos.NewUserRepository(db).UpdateEmail(ctx, user.ID, address)
+	require.NoError(t, err)
+
+	price := 2000.0
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Type:         models.AlertTypePriceBelow,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Conditions:   models.AlertConditions{Price: &price},
+		Notification: models.AlertNotification{Email: true, Webhook: "https://hooks.example.com/alerts"},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	disabled, err := repo.DisableEmailNotifications(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), disabled)
+
+	got, err := alertRepo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Notification.Email)
+	assert.Equal(t, "https://hooks.example.com/alerts", got.Notification.Webhook, "other channels stay on")
+
+	disabled, err = repo.DisableEmailNotifications(ctx, address)
+	require.NoError(t, err)
+	assert.Zero(t, disabled)
+}
@@ -0,0 +1,46 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewExperimentRepository(db)
+	experiment := "test_" + time.Now().Format("150405.000000")
+	converted, exposed, unexposed := newUser(t), newUser(t), newUser(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, repo.RecordExposure(ctx, &models.ExperimentExposure{Experiment: experiment, UserID: converted.ID, Variant: "strict", ExposedAt: now}))
+	require.NoError(t, repo.RecordExposure(ctx, &models.ExperimentExposure{Experiment: experiment, UserID: converted.ID, Variant: "control", ExposedAt: now}))
+	require.NoError(t, repo.RecordExposure(ctx, &models.ExperimentExposure{Experiment: experiment, UserID: exposed.ID, Variant: "control", ExposedAt: now}))
+
+	// Conversions count once, only after the exposure and only for exposed users
+	require.NoError(t, repo.RecordConversion(ctx, exposed.ID, models.AnalyticsWalletAdded, now.Add(-time.Minute)))
+	require.NoError(t, repo.RecordConversion(ctx, converted.ID, models.AnalyticsWalletAdded, now.Add(time.Minute)))
+	require.NoError(t, repo.RecordConversion(ctx, converted.ID, models.AnalyticsWalletAdded, now.Add(2*time.Minute)))
+	require.NoError(t, repo.RecordConversion(ctx, converted.ID, models.AnalyticsAlertTriggered, now.Add(time.Minute)))
+	require.NoError(t, repo.RecordConversion(ctx, unexposed.ID, models.AnalyticsWalletAdded, now))
+
+	results, err := repo.GetResults(ctx, experiment)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "control", results[0].Variant)
+	assert.Equal(t, 1, results[0].Exposures)
+	assert.Empty(t, results[0].Conversions)
+	assert.Equal(t, "strict", results[1].Variant, "users keep the variant first shown")
+	assert.Equal(t, 1, results[1].Exposures)
+	assert.Equal(t, []models.ExperimentConversion{
+		{Metric: models.AnalyticsAlertTriggered, Users: 1},
+		{Metric: models.AnalyticsWalletAdded, Users: 1},
+	}, results[1].Conversions)
+}
\ No newline at end of file
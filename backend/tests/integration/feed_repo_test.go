@@ -0,0 +1,76 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/feeds"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewFeedRepository(db)
+
+	url := "https://blog.example.com/" + uuid.NewString() + ".rss"
+	source := &models.FeedSource{Name: "Example blog", Kind: feeds.KindRSS, URL: url, Tokens: []string{"EXM"}, Enabled: true}
+	require.NoError(t, repo.CreateSource(ctx, source))
+	t.Cleanup(func() { repo.DeleteSource(ctx, source.ID) })
+
+	err := repo.CreateSource(ctx, &models.FeedSource{Name: "Again", Kind: feeds.KindRSS, URL: url, Tokens: []string{}, Enabled: true})
+	assert.ErrorIs(t, err, repos.ErrFeedSourceExists)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	items := []feeds.Item{
+		{GUID: "post-1", URL: url + "#1", Title: "First", PublishedAt: now.Add(-2 * time.Hour)},
+		{GUID: "post-2", URL: url + "#2", Title: "Second", PublishedAt: now.Add(-time.Hour)},
+	}
+	added, err := repo.AddItems(ctx, source.ID, items)
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	// Seen again under the same GUID or the same URL, posts aren't stored twice
+	added, err = repo.AddItems(ctx, source.ID, []feeds.Item{
+		items[0],
+		{GUID: "post-2-moved", URL: url + "#2", Title: "Second", PublishedAt: now.Add(-time.Hour)},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, added)
+
+	got, err := repo.GetItems(ctx, repos.FeedItemFilter{Limit: 100})
+	require.NoError(t, err)
+	var ours []*models.FeedItem
+	for _, item := range got {
+		if item.SourceName == "Example blog" {
+			ours = append(ours, item)
+		}
+	}
+	require.Len(t, ours, 2)
+	assert.Equal(t, "Second", ours[0].Title, "newest first")
+	assert.Equal(t, []string{"EXM"}, ours[0].Tokens)
+
+	before := now.Add(-90 * time.Minute)
+	got, err = repo.GetItems(ctx, repos.FeedItemFilter{Before: &before, Limit: 100})
+	require.NoError(t, err)
+	for _, item := range got {
+		assert.True(t, item.PublishedAt.Before(before))
+	}
+
+	// The user holds nothing, so nothing is about their holdings
+	user := newUser(t)
+	got, err = repo.GetItems(ctx, repos.FeedItemFilter{HoldingsOf: &user.ID, Limit: 100})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.NoError(t, repo.MarkFetched(ctx, source.ID, nil))
+	deleted, err := repo.DeleteSource(ctx, source.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
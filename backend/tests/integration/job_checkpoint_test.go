@@ -0,0 +1,48 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/jobs"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := jobs.NewCheckpointStore(db)
+	job := "checkpoint-test-" + uuid.NewString()[:8]
+
+	// A job that never checkpointed starts from the beginning
+	cursor, err := store.Load(ctx, job)
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+
+	require.NoError(t, store.Save(ctx, job, "first"))
+	require.NoError(t, store.Save(ctx, job, "second"))
+	cursor, err = store.Load(ctx, job)
+	require.NoError(t, err)
+	assert.Equal(t, "second", cursor)
+
+	// Progress made before a shutdown is still saved
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.NoError(t, store.Save(cancelled, job, "third"))
+	cursor, err = store.Load(ctx, job)
+	require.NoError(t, err)
+	assert.Equal(t, "third", cursor)
+
+	// Checkpoints are per job
+	other, err := store.Load(ctx, job+"-other")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	require.NoError(t, store.Clear(ctx, job))
+	cursor, err = store.Load(ctx, job)
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+}
@@ -0,0 +1,83 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/jobs"
+	"github.com/defi-dashboard/backend/pkg/jobgraph"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobLockerReplicas(t *testing.T) {
+	ctx := context.Background()
+	job := "lock-test-" + uuid.NewString()[:8]
+	graph, err := jobgraph.New([]jobgraph.Job{{Name: job, Schedule: "0 * * * * *"}})
+	require.NoError(t, err)
+
+	// Two replicas share the database, each with its own locker and scheduler
+	var runs atomic.Int32
+	replica := func() (*jobs.JobLocker, *jobs.Scheduler) {
+		locker := jobs.NewJobLocker(db)
+		scheduler := jobs.NewScheduler(graph, func(ctx context.Context, name string, run func(context.Context) error) (bool, error) {
+			return locker.RunExclusive(ctx, name, run)
+		})
+		require.NoError(t, scheduler.Bind(job, func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}))
+		// The cron is never started; ticks are driven by the test
+		require.NoError(t, scheduler.Start(ctx, cron.New(cron.WithSeconds())))
+		return locker, scheduler
+	}
+	lockerA, schedulerA := replica()
+	lockerB, schedulerB := replica()
+
+	// While one replica runs the job the other can't take it
+	running, release := make(chan struct{}), make(chan struct{})
+	done := make(chan bool)
+	go func() {
+		ran, err := lockerA.RunExclusive(ctx, job, func(context.Context) error {
+			close(running)
+			<-release
+			return nil
+		})
+		assert.NoError(t, err)
+		done <- ran
+	}()
+	<-running
+	ran, err := lockerB.RunExclusive(ctx, job, func(context.Context) error { return nil })
+	require.NoError(t, err)
+	assert.False(t, ran)
+	close(release)
+	assert.True(t, <-done)
+
+	// A replica whose cron fires after the other finished the tick doesn't run it again,
+	// however late its tick lands
+	at := time.Now().Truncate(time.Minute).Add(2*time.Minute + 200*time.Millisecond)
+	schedulerA.Tick(ctx, at)
+	assert.Equal(t, int32(1), runs.Load())
+	schedulerB.Tick(ctx, at.Add(900*time.Millisecond))
+	assert.Equal(t, int32(1), runs.Load(), "the tick already ran on the other replica")
+
+	// The next tick runs once, on whichever replica gets there first
+	schedulerB.Tick(ctx, at.Add(time.Minute))
+	schedulerA.Tick(ctx, at.Add(time.Minute))
+	assert.Equal(t, int32(2), runs.Load())
+
+	// Runs outside the schedule aren't held to ticks
+	ran, err = lockerA.RunExclusive(ctx, job, func(context.Context) error { return nil })
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	var tick time.Time
+	require.NoError(t, db.QueryRow(ctx, `SELECT tick FROM job_ticks WHERE job_name = $1`, job).Scan(&tick))
+	assert.True(t, at.Add(time.Minute).Truncate(time.Minute).Equal(tick))
+}
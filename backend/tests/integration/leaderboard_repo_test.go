@@ -0,0 +1,45 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardRepositoryParticipants(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewLeaderboardRepository(db)
+	user, other := newUser(t), newUser(t)
+
+	participant, err := repo.GetParticipant(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, participant, "users are off the boards until they opt in")
+
+	participant, err = repo.OptIn(ctx, user.ID, "anon-"+user.ID.String()[:8])
+	require.NoError(t, err)
+	assert.False(t, participant.Hidden)
+
+	again, err := repo.OptIn(ctx, user.ID, "anon-other")
+	require.NoError(t, err)
+	assert.Equal(t, participant.Alias, again.Alias, "opting in twice keeps the alias")
+
+	_, err = repo.OptIn(ctx, other.ID, participant.Alias)
+	assert.ErrorIs(t, err, repos.ErrLeaderboardAliasTaken)
+
+	found, err := repo.SetHidden(ctx, user.ID, true)
+	require.NoError(t, err)
+	assert.True(t, found)
+	found, err = repo.SetHidden(ctx, other.ID, true)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, repo.OptOut(ctx, user.ID))
+	participant, err = repo.GetParticipant(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, participant)
+}
@@ -0,0 +1,88 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricHistoryRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewMetricHistoryRepository(db)
+	start := time.Now().UTC().Truncate(time.Hour).Add(-72 * time.Hour)
+	to := start.Add(48 * time.Hour)
+
+	// Token prices are looked up by address regardless of case
+	address := fmt.Sprintf("0x%040x", time.Now().UnixNano())
+	var tokenID uuid.UUID
+	require.NoError(t, db.QueryRow(ctx, `
+		INSERT INTO tokens (address, chain_id, symbol, name, decimals)
+		VALUES ($1, 1, 'HIST', 'History Token', 18) RETURNING id`, address).Scan(&tokenID))
+	for i, price := range []float64{1.5, 2.5, 3.5} {
+		_, err := db.Exec(ctx, `INSERT INTO price_history (token_id, price_usd, timestamp) VALUES ($1, $2, $3)`,
+			tokenID, price, start.Add(time.Duration(i)*24*time.Hour))
+		require.NoError(t, err)
+	}
+
+	prices, err := repo.GetTokenPrices(ctx, address, 1, start, to)
+	require.NoError(t, err)
+	require.Len(t, prices, 2, "the snapshot at to is left out")
+	assert.Equal(t, 1.5, prices[0].Value)
+	assert.True(t, start.Equal(prices[0].Timestamp))
+	assert.Equal(t, 2.5, prices[1].Value)
+
+	none, err := repo.GetTokenPrices(ctx, address, 10, start, to)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	// Pool snapshots hold APY and TVL together
+	apy := 4.0
+	pool := &models.YieldPool{
+		PoolID:   "history-" + uuid.NewString(),
+		Protocol: &models.Protocol{Name: "Integration Protocol"},
+		PoolName: "USDC",
+		Chain:    "ethereum",
+		Symbol:   "USDC",
+		APY:      &apy,
+		IsActive: true,
+	}
+	require.NoError(t, repos.NewYieldPoolRepository(db).Upsert(ctx, pool))
+	for i, snapshot := range [][2]float64{{4, 1_000_000}, {6, 900_000}} {
+		_, err := db.Exec(ctx, `INSERT INTO yield_pool_history (pool_id, apy, tvl_usd, recorded_at) VALUES ($1, $2, $3, $4)`,
+			pool.PoolID, snapshot[0], snapshot[1], start.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+	}
+
+	apys, err := repo.GetPoolAPYs(ctx, pool.PoolID, start, to)
+	require.NoError(t, err)
+	require.Len(t, apys, 2)
+	assert.Equal(t, 4.0, apys[0].Value)
+	assert.Equal(t, 6.0, apys[1].Value)
+
+	tvls, err := repo.GetPoolTVLs(ctx, pool.PoolID, start.Add(time.Hour), to)
+	require.NoError(t, err)
+	require.Len(t, tvls, 1)
+	assert.Equal(t, 900_000.0, tvls[0].Value)
+
+	// Protocol TVLs are looked up by slug
+	slug := "history-" + uuid.NewString()
+	var protocolID uuid.UUID
+	require.NoError(t, db.QueryRow(ctx, `INSERT INTO protocols (name, slug) VALUES ($1, $1) RETURNING id`, slug).Scan(&protocolID))
+	_, err = db.Exec(ctx, `INSERT INTO protocol_tvl_history (protocol_id, tvl_usd, recorded_at) VALUES ($1, 5000000, $2)`,
+		protocolID, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	protocolTVLs, err := repo.GetProtocolTVLs(ctx, slug, start, to)
+	require.NoError(t, err)
+	require.Len(t, protocolTVLs, 1)
+	assert.Equal(t, 5_000_000.0, protocolTVLs[0].Value)
+}
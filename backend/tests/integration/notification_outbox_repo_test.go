@@ -0,0 +1,78 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationOutboxClaims(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := repos.NewAlertRepository(db, nil)
+	outboxRepo := repos.NewNotificationOutboxRepository(db, nil)
+	user := newUser(t)
+
+	price := 1850.5
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Type:         models.AlertTypePriceBelow,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Conditions:   models.AlertConditions{Price: &price},
+		Notification: models.AlertNotification{Email: true},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	history := &models.AlertHistory{
+		ID:                 uuid.New(),
+		AlertID:            alert.ID,
+		TriggeredAt:        time.Now().UTC().Truncate(time.Microsecond),
+		ConditionsSnapshot: alert.Conditions,
+		TriggeredValue:     map[string]interface{}{"price": 1849.25},
+	}
+	outboxID, err := alertRepo.RecordTrigger(ctx, history, alert.Notification, true)
+	require.NoError(t, err)
+
+	got, err := alertRepo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.TriggerCount)
+
+	claimed, err := outboxRepo.Claim(ctx, outboxID, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, history.ID, claimed.History.ID)
+	assert.Equal(t, alert.Notification, claimed.Notification)
+	assert.True(t, claimed.Urgent)
+	assert.Equal(t, 1, claimed.Attempts)
+
+	// A held row can't be claimed again until it's released and due
+	again, err := outboxRepo.Claim(ctx, outboxID, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	require.NoError(t, outboxRepo.Release(ctx, outboxID, time.Now().Add(-time.Second), "smtp down"))
+	claimed, err = outboxRepo.Claim(ctx, outboxID, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, 2, claimed.Attempts)
+	require.NotNil(t, claimed.LastError)
+	assert.Equal(t, "smtp down", *claimed.LastError)
+
+	require.NoError(t, outboxRepo.MarkDispatched(ctx, outboxID, nil))
+	again, err = outboxRepo.Claim(ctx, outboxID, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, again)
+
+	purged, err := outboxRepo.DeleteDispatchedBefore(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
@@ -0,0 +1,47 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewOnboardingRepository(db)
+	user := newUser(t)
+
+	require.NoError(t, repo.Reconcile(ctx, user.ID))
+	steps, err := repo.GetSteps(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, steps)
+
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	require.NoError(t, repo.Reconcile(ctx, user.ID))
+	steps, err = repo.GetSteps(ctx, user.ID)
+	require.NoError(t, err)
+	require.Contains(t, steps, models.OnboardingStepWalletAdded)
+	assert.True(t, wallet.CreatedAt.Equal(steps[models.OnboardingStepWalletAdded]), "dated by the wallet")
+
+	owner, recorded, err := repo.CompleteWalletStep(ctx, wallet.ID, models.OnboardingStepFirstSync)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, owner)
+	assert.True(t, recorded)
+	_, recorded, err = repo.CompleteWalletStep(ctx, wallet.ID, models.OnboardingStepFirstSync)
+	require.NoError(t, err)
+	assert.False(t, recorded)
+
+	recorded, err = repo.CompleteStep(ctx, user.ID, models.OnboardingStepWalletAdded)
+	require.NoError(t, err)
+	assert.False(t, recorded)
+	steps, err = repo.GetSteps(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, steps, 2)
+}
\ No newline at end of file
@@ -0,0 +1,56 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaperPortfolioRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewPaperPortfolioRepository(db)
+	user := newUser(t)
+
+	portfolio := &models.PaperPortfolio{UserID: user.ID, Name: "Momentum", StartingCashUSD: 1000}
+	require.NoError(t, repo.Create(ctx, portfolio))
+	assert.Equal(t, 1000.0, portfolio.CashUSD)
+
+	err := repo.Create(ctx, &models.PaperPortfolio{UserID: user.ID, Name: "Momentum", StartingCashUSD: 1000})
+	assert.ErrorIs(t, err, repos.ErrPaperPortfolioNameTaken)
+
+	_, err = repo.AddTrade(ctx, &models.PaperTrade{PortfolioID: portfolio.ID, Side: models.PaperTradeBuy, Asset: "ETH", Quantity: 1, PriceUSD: 2000})
+	assert.ErrorIs(t, err, repos.ErrPaperInsufficientCash)
+
+	updated, err := repo.AddTrade(ctx, &models.PaperTrade{PortfolioID: portfolio.ID, Side: models.PaperTradeBuy, Asset: "ETH", Quantity: 0.25, PriceUSD: 2000})
+	require.NoError(t, err)
+	assert.InDelta(t, 500, updated.CashUSD, 1e-9)
+
+	updated, err = repo.AddTrade(ctx, &models.PaperTrade{PortfolioID: portfolio.ID, Side: models.PaperTradeYield, Asset: "ETH", Quantity: 0.05, PriceUSD: 2000})
+	require.NoError(t, err)
+	assert.InDelta(t, 500, updated.CashUSD, 1e-9, "yield costs no cash")
+
+	_, err = repo.AddTrade(ctx, &models.PaperTrade{PortfolioID: portfolio.ID, Side: models.PaperTradeSell, Asset: "ETH", Quantity: 0.31, PriceUSD: 2000})
+	assert.ErrorIs(t, err, repos.ErrPaperInsufficientHoldings)
+
+	updated, err = repo.AddTrade(ctx, &models.PaperTrade{PortfolioID: portfolio.ID, Side: models.PaperTradeSell, Asset: "ETH", Quantity: 0.3, PriceUSD: 3000})
+	require.NoError(t, err)
+	assert.InDelta(t, 1400, updated.CashUSD, 1e-9)
+
+	trades, err := repo.GetTrades(ctx, portfolio.ID)
+	require.NoError(t, err)
+	require.Len(t, trades, 3)
+	assert.Equal(t, models.PaperTradeSell, trades[2].Side)
+
+	deleted, err := repo.Delete(ctx, portfolio.ID, user.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	trades, err = repo.GetTrades(ctx, portfolio.ID)
+	require.NoError(t, err)
+	assert.Empty(t, trades, "trades go with their portfolio")
+}
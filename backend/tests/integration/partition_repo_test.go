@@ -0,0 +1,118 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionRepository_EnsureMonthlyPartitions(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewPartitionRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+
+	from := time.Date(2090, 1, 20, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2090, 2, 3, 0, 0, 0, 0, time.UTC)
+	created, err := repo.EnsureMonthlyPartitions(ctx, "alert_history", from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+
+	created, err = repo.EnsureMonthlyPartitions(ctx, "alert_history", from, to)
+	require.NoError(t, err)
+	assert.Zero(t, created, "existing partitions are left alone")
+
+	price := 1850.5
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       newUser(t).ID,
+		Type:         models.AlertTypePriceBelow,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Conditions:   models.AlertConditions{Price: &price},
+		Notification: models.AlertNotification{Email: true},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	// A trigger lands in its month's partition, including at the very end of the month
+	for _, tt := range []struct {
+		at        time.Time
+		partition string
+	}{
+		{time.Date(2090, 1, 31, 23, 59, 59, 0, time.UTC), "alert_history_2090_01"},
+		{time.Date(2090, 2, 1, 0, 0, 0, 0, time.UTC), "alert_history_2090_02"},
+		{time.Date(2091, 6, 1, 0, 0, 0, 0, time.UTC), "alert_history_default"},
+	} {
+		history := &models.AlertHistory{ID: uuid.New(), AlertID: alert.ID, TriggeredAt: tt.at, ConditionsSnapshot: alert.Conditions}
+		require.NoError(t, alertRepo.CreateHistory(ctx, history))
+
+		var partition string
+		require.NoError(t, db.QueryRow(ctx, `SELECT tableoid::regclass::text FROM alert_history WHERE id = $1`, history.ID).Scan(&partition))
+		assert.Equal(t, tt.partition, partition)
+	}
+
+	// The default partition holds rows for June 2091, so that month is skipped
+	created, err = repo.EnsureMonthlyPartitions(ctx, "alert_history",
+		time.Date(2091, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2091, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+}
+
+func TestPartitionRepository_DropMonthlyPartitions(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewPartitionRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+
+	// Months long before any other test's partitions, so dropping them touches nothing else
+	_, err := repo.EnsureMonthlyPartitions(ctx, "alert_history",
+		time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1990, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	price := 1850.5
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       newUser(t).ID,
+		Type:         models.AlertTypePriceBelow,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Conditions:   models.AlertConditions{Price: &price},
+		Notification: models.AlertNotification{Email: true},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	expired := &models.AlertHistory{ID: uuid.New(), AlertID: alert.ID, TriggeredAt: time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC), ConditionsSnapshot: alert.Conditions}
+	outboxID, err := alertRepo.RecordTrigger(ctx, expired, alert.Notification, false)
+	require.NoError(t, err)
+	kept := &models.AlertHistory{ID: uuid.New(), AlertID: alert.ID, TriggeredAt: time.Date(1990, 2, 10, 0, 0, 0, 0, time.UTC), ConditionsSnapshot: alert.Conditions}
+	require.NoError(t, alertRepo.CreateHistory(ctx, kept))
+
+	// References must name the trigger's time as well as its ID
+	_, err = db.Exec(ctx, `
+		INSERT INTO notification_outbox (alert_id, history_id, history_triggered_at, notification, urgent)
+		VALUES ($1, $2, $3, '{}', FALSE)`, alert.ID, kept.ID, kept.TriggeredAt.Add(time.Second))
+	assert.Error(t, err)
+
+	// Only the month ending by the cutoff goes, with the outbox row of its trigger
+	dropped, err := repo.DropMonthlyPartitions(ctx, "alert_history", time.Date(1990, 2, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+
+	var exists bool
+	require.NoError(t, db.QueryRow(ctx, `SELECT to_regclass('alert_history_1990_01') IS NOT NULL`).Scan(&exists))
+	assert.False(t, exists)
+	require.NoError(t, db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM notification_outbox WHERE id = $1)`, outboxID).Scan(&exists))
+	assert.False(t, exists)
+	require.NoError(t, db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM alert_history WHERE id = $1)`, kept.ID).Scan(&exists))
+	assert.True(t, exists)
+
+	// Cleanup for reruns
+	_, err = repo.DropMonthlyPartitions(ctx, "alert_history", time.Date(1990, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+}
@@ -0,0 +1,75 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewPlanRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+	user, admin := newUser(t), newUser(t)
+
+	// Users start on the free plan
+	plan, err := repo.GetPlan(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanTierFree, plan.Tier)
+	assert.Nil(t, plan.UpdatedAt)
+
+	plan.Tier = models.PlanTierPro
+	plan.LimitOverrides = map[string]int{models.QuotaWallets: 80}
+	plan.UpdatedBy = &admin.ID
+	found, err := repo.SetPlan(ctx, plan)
+	require.NoError(t, err)
+	assert.True(t, found)
+	saved, err := repo.GetPlan(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanTierPro, saved.Tier)
+	assert.Equal(t, map[string]int{models.QuotaWallets: 80}, saved.LimitOverrides)
+	assert.Equal(t, admin.ID, *saved.UpdatedBy)
+	require.NotNil(t, saved.UpdatedAt)
+
+	found, err = repo.SetPlan(ctx, &models.UserPlan{UserID: uuid.New(), Tier: models.PlanTierPro})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	for _, address := range []string{"0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B", "0xab5801a7d398351b8be11c439e05c5b3259aec9b"} {
+		require.NoError(t, alertRepo.Create(ctx, &models.Alert{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			Type:   models.AlertTypeLargeTransfer,
+			Status: models.AlertStatusActive,
+			Target: models.AlertTarget{Type: "address", Identifier: address, ChainID: 1},
+		}))
+	}
+	require.NoError(t, repos.NewWebhookVerificationRepository(db).MarkVerified(ctx, user.ID, "hash", time.Now()))
+
+	usage, err := repo.GetUsage(ctx, user.ID, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		models.QuotaWallets:          1,
+		models.QuotaAlerts:           2,
+		models.QuotaWatchedAddresses: 1,
+		models.QuotaExportsPerDay:    0,
+		models.QuotaWebhookChannels:  1,
+	}, usage)
+
+	watched, err := repo.WatchesAddress(ctx, user.ID, "0xAB5801A7D398351B8BE11C439E05C5B3259AEC9B")
+	require.NoError(t, err)
+	assert.True(t, watched)
+	watched, err = repo.WatchesAddress(ctx, admin.ID, "0xab5801a7d398351b8be11c439e05c5b3259aec9b")
+	require.NoError(t, err)
+	assert.False(t, watched)
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/pkg/decimal"
+	"github.com/defi-dashboard/backend/pkg/pnl"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedLot builds a lot for a fixture transaction
+func seedLot(t *testing.T, wallet, token, transaction, lotType, quantity, price string) *models.PnLLot {
+	t.Helper()
+	hash := fixtures.Hash(transaction)
+	for _, tx := range seed.Transactions {
+		if tx.Hash == hash {
+			return &models.PnLLot{
+				ID:                uuid.New(),
+				WalletID:          fixtures.ID(wallet),
+				TokenID:           fixtures.ID(token),
+				TransactionHash:   hash,
+				ChainID:           tx.ChainID,
+				Type:              lotType,
+				Quantity:          quantity,
+				PriceUSD:          price,
+				RemainingQuantity: quantity,
+				BlockNumber:       tx.Block,
+				Timestamp:         tx.Timestamp,
+			}
+		}
+	}
+	t.Fatalf("no fixture transaction %s", transaction)
+	return nil
+}
+
+func assertDecimal(t *testing.T, expected, actual string) {
+	t.Helper()
+	assert.True(t, decimal.MustParse(expected).Equal(decimal.MustParse(actual)), "expected %s, got %s", expected, actual)
+}
+
+func TestPnLRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := pnl.NewRepository(db)
+
+	bought := seedLot(t, "wallet:alice:1", "token:1:ETH", "alice:receive-eth", "buy", "2", "3010.55")
+	bridgedOut := seedLot(t, "wallet:alice:1", "token:1:ETH", "alice:bridge-arbitrum", "sell", "0.8", "3150.42")
+	bridgedIn := seedLot(t, "wallet:alice:42161", "token:42161:ETH", "alice:bridge-arbitrum", "buy", "0.8", "3150.42")
+	testnet := seedLot(t, "wallet:alice:80002", "token:80002:MATIC", "alice:polygon-receive", "buy", "25", "0")
+	for _, lot := range []*models.PnLLot{bought, bridgedOut, bridgedIn, testnet} {
+		require.NoError(t, repo.CreateLot(ctx, lot))
+	}
+	assert.Equal(t, models.SourceOnchain, bought.Source)
+
+	lots, err := repo.GetLotsByWalletAndToken(ctx, bought.WalletID, bought.TokenID)
+	require.NoError(t, err)
+	require.Len(t, lots, 2)
+	assert.Equal(t, bought.ID, lots[0].ID, "lots come oldest first")
+	assertDecimal(t, "2", lots[0].Quantity)
+	assertDecimal(t, "3010.55", lots[0].PriceUSD)
+	assert.True(t, bought.Timestamp.Equal(lots[0].Timestamp))
+	assert.Nil(t, lots[0].InternalTransferID)
+
+	require.NoError(t, repo.UpdateLotRemainingQuantity(ctx, bought.ID, "1.2"))
+	lots, err = repo.GetLotsByWallet(ctx, bought.WalletID, bought.TokenID, bought.Timestamp, bought.Timestamp)
+	require.NoError(t, err)
+	require.Len(t, lots, 1)
+	assertDecimal(t, "1.2", lots[0].RemainingQuantity)
+
+	tokens, err := repo.GetWalletTokens(ctx, bought.WalletID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{bought.TokenID}, tokens)
+
+	unmatched, err := repo.GetUnmatchedTransferLots(ctx, fixtureTime.AddDate(-1, 0, 0))
+	require.NoError(t, err)
+	ids := make(map[uuid.UUID]pnl.TransferLot)
+	for _, lot := range unmatched {
+		ids[lot.Lot.ID] = lot
+	}
+	require.Contains(t, ids, bridgedOut.ID)
+	require.Contains(t, ids, bridgedIn.ID)
+	assert.Contains(t, ids, bought.ID)
+	assert.NotContains(t, ids, testnet.ID, "testnet wallets are never matched")
+	assert.Equal(t, fixtures.ID("user:alice"), ids[bridgedOut.ID].UserID)
+	assert.Equal(t, "bridge", ids[bridgedOut.ID].TransactionType)
+
+	costBasis := "3010.55"
+	transfer := &pnl.InternalTransfer{
+		UserID:       fixtures.ID("user:alice"),
+		Kind:         "bridge",
+		Out:          *bridgedOut,
+		In:           *bridgedIn,
+		CostBasisUSD: &costBasis,
+	}
+	created, err := repo.CreateInternalTransfer(ctx, transfer)
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = repo.CreateInternalTransfer(ctx, transfer)
+	require.NoError(t, err)
+	assert.False(t, created, "a pairing is only recorded once")
+
+	lots, err = repo.GetLotsByWalletAndToken(ctx, bridgedIn.WalletID, bridgedIn.TokenID)
+	require.NoError(t, err)
+	require.Len(t, lots, 1)
+	require.NotNil(t, lots[0].InternalTransferID)
+	require.NotNil(t, lots[0].CostBasisUSD)
+	assertDecimal(t, costBasis, *lots[0].CostBasisUSD)
+
+	unmatched, err = repo.GetUnmatchedTransferLots(ctx, fixtureTime.AddDate(-1, 0, 0))
+	require.NoError(t, err)
+	for _, lot := range unmatched {
+		assert.NotEqual(t, bridgedOut.ID, lot.Lot.ID)
+		assert.NotEqual(t, bridgedIn.ID, lot.Lot.ID)
+	}
+}
@@ -0,0 +1,83 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionRepositoryGetProtocolInteractions(t *testing.T) {
+	ctx := context.Background()
+	backfillRepo := repos.NewWalletBackfillRepository(db)
+	repo := repos.NewTransactionRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	_, err = backfillRepo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	backfill, err := backfillRepo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, backfill)
+	require.NoError(t, backfillRepo.Start(ctx, backfill, 199))
+
+	router := "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	pool := "0x87870bca3f3fd6335c3f4ce8392d69350b4fa4e2"
+	stranger := "0x1111111111111111111111111111111111111111"
+	start := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	newTx := func(i int, from, to, value, status string) *models.Transaction {
+		block := int64(10 + i)
+		return &models.Transaction{
+			Hash:        fmt.Sprintf("0x%s%056d", uuid.NewString()[:8], i),
+			ChainID:     1,
+			FromAddress: from,
+			ToAddress:   &to,
+			Value:       &value,
+			BlockNumber: &block,
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			Status:      status,
+			Type:        "send",
+		}
+	}
+	transactions := []*models.Transaction{
+		newTx(0, wallet.Address, router, "100", models.TransactionStatusConfirmed),
+		newTx(1, wallet.Address, router, "250", models.TransactionStatusConfirmed),
+		newTx(2, router, wallet.Address, "50", models.TransactionStatusConfirmed),
+		newTx(3, wallet.Address, router, "999", models.TransactionStatusFailed),
+		newTx(4, wallet.Address, pool, "0", models.TransactionStatusConfirmed),
+		newTx(5, wallet.Address, stranger, "1", models.TransactionStatusConfirmed),
+	}
+	require.NoError(t, backfillRepo.SaveChunk(ctx, backfill, transactions, 200))
+
+	interactions, err := repo.GetProtocolInteractions(ctx, user.ID, wallet.Address)
+	require.NoError(t, err)
+	require.Len(t, interactions, 2)
+
+	uniswap := interactions[0]
+	assert.Equal(t, blockchain.ProtocolUniswap, uniswap.Protocol)
+	assert.Equal(t, "Uniswap", uniswap.Name)
+	assert.Equal(t, 1, uniswap.ChainID)
+	assert.Equal(t, 3, uniswap.TxCount, "failed transactions aren't counted")
+	assert.Equal(t, "400", uniswap.Volume)
+	assert.True(t, uniswap.LastInteraction.Equal(transactions[2].Timestamp))
+
+	aave := interactions[1]
+	assert.Equal(t, blockchain.ProtocolAaveV3, aave.Protocol)
+	assert.Equal(t, 1, aave.TxCount)
+	assert.Equal(t, "0", aave.Volume)
+
+	other := newUser(t)
+	none, err := repo.GetProtocolInteractions(ctx, other.ID, wallet.Address)
+	require.NoError(t, err)
+	assert.Empty(t, none, "other users' wallets aren't summarized")
+}
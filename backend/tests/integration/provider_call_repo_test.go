@@ -0,0 +1,64 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderCallRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewProviderCallRepository(db)
+
+	requestID := uuid.NewString()
+	status, hash, size := 200, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", int64(512)
+	failure := "connection refused"
+	started := time.Now().UTC().Truncate(time.Millisecond)
+	calls := []models.ProviderCall{
+		{RequestID: &requestID, Provider: "lifi", Method: "GET", Host: "li.quest", Endpoint: "/v1/quote?fromChain=1",
+			Status: &status, DurationMs: 120, ResponseHash: &hash, ResponseBytes: &size,
+			Source: models.ProviderCallSourceAPI, StartedAt: started},
+		{RequestID: &requestID, Provider: "socket", Method: "GET", Host: "api.socket.tech", Endpoint: "/v2/quote",
+			DurationMs: 3000, Error: &failure, Source: models.ProviderCallSourceAPI, StartedAt: started.Add(time.Second)},
+		{Provider: "coingecko", Method: "GET", Host: "api.coingecko.com", Endpoint: "/api/v3/simple/price",
+			Status: &status, DurationMs: 80, ResponseHash: &hash, ResponseBytes: &size,
+			Source: models.ProviderCallSourceWorker, StartedAt: started.Add(-30 * 24 * time.Hour)},
+	}
+	require.NoError(t, repo.InsertBatch(ctx, calls))
+
+	// A request's calls come back newest first
+	got, err := repo.List(ctx, models.ProviderCallFilter{RequestID: requestID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "socket", got[0].Provider)
+	assert.Nil(t, got[0].Status)
+	assert.Equal(t, failure, *got[0].Error)
+	assert.Equal(t, "lifi", got[1].Provider)
+	assert.Equal(t, hash, *got[1].ResponseHash)
+	assert.Equal(t, size, *got[1].ResponseBytes)
+	assert.True(t, started.Equal(got[1].StartedAt))
+
+	from := started.Add(-time.Minute)
+	got, err = repo.List(ctx, models.ProviderCallFilter{Provider: "lifi", From: &from, Limit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, got)
+	for _, c := range got {
+		assert.Equal(t, "lifi", c.Provider)
+	}
+
+	// Pruning drops calls older than the cutoff only
+	pruned, err := repo.DeleteBefore(ctx, started.Add(-7*24*time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, pruned, int64(1))
+	got, err = repo.List(ctx, models.ProviderCallFilter{RequestID: requestID, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
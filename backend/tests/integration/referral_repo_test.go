@@ -0,0 +1,78 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferralRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewReferralRepository(db)
+	referrer, referred, other := newUser(t), newUser(t), newUser(t)
+	code := "T" + referrer.ID.String()[:7]
+
+	created, err := repo.CreateCode(ctx, referrer.ID, code)
+	require.NoError(t, err)
+	assert.Equal(t, code, created)
+	created, err = repo.CreateCode(ctx, referrer.ID, "OTHERCDE")
+	require.NoError(t, err)
+	assert.Equal(t, code, created, "a user keeps their first code")
+	created, err = repo.CreateCode(ctx, other.ID, code)
+	require.NoError(t, err)
+	assert.Empty(t, created, "a code belongs to one user")
+
+	referrerID, ok, err := repo.GetReferrer(ctx, code)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, referrer.ID, referrerID)
+
+	referral := &models.Referral{ReferrerID: referrer.ID, ReferredID: referred.ID, Code: code,
+		Status: models.ReferralRewarded, ReferrerRewardDays: 30, ReferredRewardDays: 14}
+	ok, err = repo.Create(ctx, referral)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.Create(ctx, &models.Referral{ReferrerID: other.ID, ReferredID: referred.ID, Code: code, Status: models.ReferralRewarded})
+	require.NoError(t, err)
+	assert.False(t, ok, "a user is referred once")
+
+	stats, err := repo.GetStats(ctx, referrer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Referred)
+	assert.Equal(t, 1, stats.Rewarded)
+	assert.Equal(t, 30, stats.RewardDays)
+	saved, err := repo.GetByReferred(ctx, referred.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, referral.ID, saved.ID)
+
+	// Tracking the referrer's sign-in address counts as sharing a wallet
+	shared, err := repo.SharesWallet(ctx, referrer.ID, other.ID)
+	require.NoError(t, err)
+	assert.False(t, shared)
+	_, err = repos.NewWalletRepository(db).Create(ctx, other.ID, referrer.Address, 1, nil, true)
+	require.NoError(t, err)
+	shared, err = repo.SharesWallet(ctx, referrer.ID, other.ID)
+	require.NoError(t, err)
+	assert.True(t, shared)
+
+	// Trials extend from their current end
+	plans := repos.NewPlanRepository(db)
+	first, err := plans.ExtendTrial(ctx, referred.ID, 24*time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	second, err := plans.ExtendTrial(ctx, referred.ID, 24*time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, first.Add(24*time.Hour), *second, time.Second)
+	plan, err := plans.GetPlan(ctx, referred.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PlanTierFree, plan.Tier)
+	assert.Equal(t, models.PlanTierPro, plan.EffectiveTier(time.Now()))
+}
\ No newline at end of file
@@ -0,0 +1,69 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardLockRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewRewardLockRepository(db)
+	user := newUser(t)
+	wallet := "0x1111111111111111111111111111111111111111"
+	veCRV := "0x5f3b5DfEb7B28CDbD7FAba78963EE202a494e2A2"
+	veBAL := "0xC128a9954e6c874eA3d62ce62B468bA073093F25"
+
+	onchain := func(contract string, amount float64) *models.RewardLock {
+		power := amount / 2
+		return &models.RewardLock{
+			UserID: user.ID, WalletAddress: wallet, ChainID: 1, Protocol: "curve",
+			Kind: models.RewardLockKindVoteEscrow, Source: models.RewardLockSourceOnchain,
+			Contract: contract, TokenAddress: "0xD533a949740bb3306d119CC777fa900bA034cd52", TokenSymbol: "CRV",
+			Amount: amount, VotingPower: &power, UnlocksAt: time.Now().AddDate(1, 0, 0).UTC().Truncate(time.Second),
+		}
+	}
+
+	require.NoError(t, repo.ReplaceOnchain(ctx, user.ID, wallet, 1, []*models.RewardLock{onchain(veCRV, 100), onchain(veBAL, 5)}))
+	// A later sync updates the lock in place and forgets the withdrawn one
+	require.NoError(t, repo.ReplaceOnchain(ctx, user.ID, wallet, 1, []*models.RewardLock{onchain(veCRV, 150)}))
+
+	start := time.Now().UTC().Truncate(time.Second)
+	vesting := &models.RewardLock{
+		UserID: user.ID, WalletAddress: wallet, ChainID: 42161, Protocol: "gmx",
+		Kind: models.RewardLockKindVesting, Source: models.RewardLockSourceManual,
+		Contract: "0x2222222222222222222222222222222222222222", TokenAddress: "0x3333333333333333333333333333333333333333", TokenSymbol: "GMX",
+		Amount: 365, StartsAt: &start, UnlocksAt: start.AddDate(0, 6, 0),
+	}
+	require.NoError(t, repo.Create(ctx, vesting))
+
+	locks, err := repo.GetByUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, locks, 2)
+	assert.Equal(t, vesting.ID, locks[0].ID, "soonest unlock first")
+	assert.Equal(t, "0x5f3b5dfeb7b28cdbd7faba78963ee202a494e2a2", locks[1].Contract)
+	assert.Equal(t, 150.0, locks[1].Amount)
+	require.NotNil(t, locks[1].VotingPower)
+	assert.Equal(t, 75.0, *locks[1].VotingPower)
+
+	// Syncing leaves manual schedules alone
+	require.NoError(t, repo.ReplaceOnchain(ctx, user.ID, wallet, 42161, nil))
+	found, err := repo.GetByID(ctx, vesting.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, start, found.StartsAt.UTC())
+
+	deleted, err := repo.Delete(ctx, vesting.ID, newUser(t).ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = repo.Delete(ctx, vesting.ID, user.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
@@ -0,0 +1,72 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedSearchRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewSavedSearchRepository(db)
+	user := newUser(t)
+
+	token := "share-" + uuid.NewString()
+	search := &models.SavedSearch{
+		UserID:     user.ID,
+		Name:       "Stable farms",
+		Kind:       models.SavedSearchKindYieldPools,
+		Params:     map[string]string{"stablecoin": "true", "risk": "low"},
+		ShareToken: &token,
+	}
+	require.NoError(t, repo.Create(ctx, search))
+	assert.NotEqual(t, uuid.Nil, search.ID)
+
+	// Names are unique per user and kind
+	err := repo.Create(ctx, &models.SavedSearch{UserID: user.ID, Name: "Stable farms", Kind: models.SavedSearchKindYieldPools})
+	assert.Equal(t, repos.ErrSavedSearchNameTaken, err)
+	require.NoError(t, repo.Create(ctx, &models.SavedSearch{UserID: user.ID, Name: "Stable farms", Kind: models.SavedSearchKindTransactions, Params: map[string]string{}}))
+
+	shared, err := repo.GetByShareToken(ctx, token)
+	require.NoError(t, err)
+	require.NotNil(t, shared)
+	assert.Equal(t, search.Params, shared.Params)
+
+	searches, err := repo.GetByUser(ctx, user.ID, models.SavedSearchKindYieldPools)
+	require.NoError(t, err)
+	require.Len(t, searches, 1)
+	searches, err = repo.GetByUser(ctx, user.ID, "")
+	require.NoError(t, err)
+	assert.Len(t, searches, 2)
+
+	// Revoking the share link stops the token resolving
+	search.Name = "Low risk stables"
+	search.ShareToken = nil
+	require.NoError(t, repo.Update(ctx, search))
+	shared, err = repo.GetByShareToken(ctx, token)
+	require.NoError(t, err)
+	assert.Nil(t, shared)
+
+	got, err := repo.GetByID(ctx, search.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Low risk stables", got.Name)
+
+	// Other users can't delete it
+	deleted, err := repo.Delete(ctx, search.ID, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = repo.Delete(ctx, search.ID, user.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	got, err = repo.GetByID(ctx, search.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
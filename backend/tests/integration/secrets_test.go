@@ -0,0 +1,101 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/crypto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertWebhooksSealedAtRest(t *testing.T) {
+	ctx := context.Background()
+	encryptor, err := crypto.NewEncryptor(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	repo := repos.NewAlertRepository(db, encryptor)
+	user := newUser(t)
+
+	notification := models.AlertNotification{Webhook: "https://hooks.example.com/secret-token", Discord: "https://discord.com/api/webhooks/1/token"}
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Type:         models.AlertTypePriceAbove,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Notification: notification,
+	}
+	require.NoError(t, repo.Create(ctx, alert))
+	defer repo.Delete(ctx, alert.ID)
+	assert.Equal(t, notification, alert.Notification, "the caller's alert keeps its plaintext")
+
+	var stored string
+	require.NoError(t, db.QueryRow(ctx, `SELECT notification::text FROM alerts WHERE id = $1`, alert.ID).Scan(&stored))
+	assert.NotContains(t, stored, "secret-token")
+	assert.Contains(t, stored, "enc:v1:")
+
+	got, err := repo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, notification, got.Notification)
+
+	// Queued notifications are sealed the same way
+	history := &models.AlertHistory{ID: uuid.New(), AlertID: alert.ID, TriggeredAt: time.Now()}
+	outboxID, err := repo.RecordTrigger(ctx, history, got.Notification, false)
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRow(ctx, `SELECT notification::text FROM notification_outbox WHERE id = $1`, outboxID).Scan(&stored))
+	assert.NotContains(t, stored, "secret-token")
+	claimed, err := repos.NewNotificationOutboxRepository(db, encryptor).Claim(ctx, outboxID, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, notification, claimed.Notification)
+
+	// Without the key the alert can't be read
+	_, err = repos.NewAlertRepository(db, nil).GetByID(ctx, alert.ID)
+	assert.Error(t, err)
+}
+
+func TestRotateOutboxNotifications(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := bytes.Repeat([]byte{7}, 32), bytes.Repeat([]byte{8}, 32)
+	old, err := crypto.NewEncryptor(oldKey)
+	require.NoError(t, err)
+	alertRepo := repos.NewAlertRepository(db, old)
+	user := newUser(t)
+
+	notification := models.AlertNotification{Webhook: "https://hooks.example.com/secret-token"}
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Type:         models.AlertTypePriceAbove,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Notification: notification,
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+	defer alertRepo.Delete(ctx, alert.ID)
+	history := &models.AlertHistory{ID: uuid.New(), AlertID: alert.ID, TriggeredAt: time.Now()}
+	outboxID, err := alertRepo.RecordTrigger(ctx, history, notification, false)
+	require.NoError(t, err)
+
+	keyring, err := crypto.NewKeyring(newKey, oldKey)
+	require.NoError(t, err)
+	secretRepo := repos.NewSecretRepository(db)
+	rotated, err := secretRepo.RotateOutboxNotifications(ctx, keyring)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, rotated, 1)
+
+	// The queued notification can be sent once the old key is dropped
+	current, err := crypto.NewEncryptor(newKey)
+	require.NoError(t, err)
+	claimed, err := repos.NewNotificationOutboxRepository(db, current).Claim(ctx, outboxID, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, notification, claimed.Notification)
+}
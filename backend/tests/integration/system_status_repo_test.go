@@ -0,0 +1,93 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemStatusRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewSystemStatusRepository(db)
+
+	job := "status-test-" + uuid.NewString()[:8]
+	started := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.RecordJobRun(ctx, job, started, started.Add(1500*time.Millisecond), nil, ""))
+
+	runs, err := repo.GetJobRuns(ctx)
+	require.NoError(t, err)
+	require.Contains(t, runs, job)
+	succeeded := runs[job].LastSuccessAt
+	require.NotNil(t, succeeded)
+	assert.True(t, started.Add(1500*time.Millisecond).Equal(*succeeded))
+	assert.Equal(t, 1500, runs[job].LastDurationMs)
+	assert.Nil(t, runs[job].LastError)
+
+	// A failed run keeps the last success
+	require.NoError(t, repo.RecordJobRun(ctx, job, started.Add(time.Minute), started.Add(time.Minute+time.Second), fmt.Errorf("rpc timeout"), ""))
+	runs, err = repo.GetJobRuns(ctx)
+	require.NoError(t, err)
+	assert.True(t, succeeded.Equal(*runs[job].LastSuccessAt))
+	require.NotNil(t, runs[job].LastError)
+	assert.Equal(t, "rpc timeout", *runs[job].LastError)
+	assert.True(t, started.Add(time.Minute).Equal(runs[job].LastStartedAt))
+
+	// A degraded run succeeds but says why; the next run clears it
+	require.NoError(t, repo.RecordJobRun(ctx, job, started.Add(2*time.Minute), started.Add(2*time.Minute+time.Second), nil, "skipped 3 alerts on stale data"))
+	runs, err = repo.GetJobRuns(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, runs[job].LastError)
+	require.NotNil(t, runs[job].LastDegraded)
+	assert.Equal(t, "skipped 3 alerts on stale data", *runs[job].LastDegraded)
+	require.NoError(t, repo.RecordJobRun(ctx, job, started.Add(3*time.Minute), started.Add(3*time.Minute+time.Second), nil, ""))
+	runs, err = repo.GetJobRuns(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, runs[job].LastDegraded)
+
+	// The fixtures load prices and pools
+	freshness, err := repo.GetDataFreshness(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, freshness, repos.DataSourcePrices)
+	assert.Contains(t, freshness, repos.DataSourcePools)
+}
+
+func TestProviderCallHealth(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewProviderCallRepository(db)
+
+	provider := "health-" + uuid.NewString()[:8]
+	ok, throttled, failed := 200, 429, 503
+	failure := "connection reset"
+	now := time.Now().UTC()
+	calls := []models.ProviderCall{
+		{Provider: provider, Method: "GET", Host: "example.com", Endpoint: "/", Status: &ok, Source: models.ProviderCallSourceWorker, StartedAt: now},
+		{Provider: provider, Method: "GET", Host: "example.com", Endpoint: "/", Status: &ok, Source: models.ProviderCallSourceWorker, StartedAt: now},
+		{Provider: provider, Method: "GET", Host: "example.com", Endpoint: "/", Status: &throttled, Source: models.ProviderCallSourceWorker, StartedAt: now},
+		{Provider: provider, Method: "GET", Host: "example.com", Endpoint: "/", Status: &failed, Source: models.ProviderCallSourceWorker, StartedAt: now},
+		{Provider: provider, Method: "GET", Host: "example.com", Endpoint: "/", Error: &failure, Source: models.ProviderCallSourceWorker, StartedAt: now},
+		// Outside the window
+		{Provider: provider, Method: "GET", Host: "example.com", Endpoint: "/", Error: &failure, Source: models.ProviderCallSourceWorker, StartedAt: now.Add(-time.Hour)},
+	}
+	require.NoError(t, repo.InsertBatch(ctx, calls))
+
+	health, err := repo.GetHealth(ctx, now.Add(-15*time.Minute))
+	require.NoError(t, err)
+	var found *models.ProviderHealth
+	for i := range health {
+		if health[i].Provider == provider {
+			found = &health[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, 5, found.Calls)
+	assert.Equal(t, 3, found.Failures)
+}
\ No newline at end of file
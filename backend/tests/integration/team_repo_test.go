@@ -0,0 +1,229 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewTeamRepository(db)
+	owner, member := newUser(t), newUser(t)
+
+	team := &models.Team{Name: "Desk", CreatedBy: owner.ID}
+	require.NoError(t, repo.Create(ctx, team))
+	assert.NotEqual(t, uuid.Nil, team.ID)
+
+	role, err := repo.GetRole(ctx, team.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TeamRoleOwner, role)
+
+	require.NoError(t, repo.AddMember(ctx, &models.TeamMember{TeamID: team.ID, UserID: member.ID, Role: models.TeamRoleMember}))
+	err = repo.AddMember(ctx, &models.TeamMember{TeamID: team.ID, UserID: member.ID, Role: models.TeamRoleMember})
+	assert.ErrorIs(t, err, repos.ErrTeamMemberExists)
+
+	members, err := repo.GetMembers(ctx, team.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, owner.Address, members[0].Address)
+
+	teams, err := repo.GetByUser(ctx, member.ID)
+	require.NoError(t, err)
+	require.Len(t, teams, 1)
+	assert.Equal(t, models.TeamRoleMember, teams[0].Role)
+
+	policy := &models.EscalationPolicy{
+		TeamID: team.ID,
+		Name:   "Primary",
+		Steps: []models.EscalationStep{
+			{Channel: models.NotificationChannelDiscord},
+			{DelayMinutes: 10, Channel: models.NotificationChannelEmail, UserID: &member.ID},
+		},
+	}
+	require.NoError(t, repo.CreatePolicy(ctx, policy))
+	got, err := repo.GetPolicy(ctx, policy.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, policy.Steps, got.Steps)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	shift := &models.OnCallShift{TeamID: team.ID, UserID: member.ID, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	require.NoError(t, repo.CreateShift(ctx, shift))
+	shifts, err := repo.GetShifts(ctx, team.ID, now, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, member.ID, shifts[0].UserID)
+	shifts, err = repo.GetShifts(ctx, team.ID, now.Add(time.Hour), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, shifts)
+
+	removed, err := repo.RemoveMember(ctx, team.ID, member.ID)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	deleted, err := repo.DeletePolicy(ctx, team.ID, policy.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
+
+func TestEscalationRepository(t *testing.T) {
+	ctx := context.Background()
+	alertRepo := repos.NewAlertRepository(db, nil)
+	teamRepo := repos.NewTeamRepository(db)
+	repo := repos.NewEscalationRepository(db)
+	owner := newUser(t)
+
+	team := &models.Team{Name: "Desk", CreatedBy: owner.ID}
+	require.NoError(t, teamRepo.Create(ctx, team))
+	policy := &models.EscalationPolicy{TeamID: team.ID, Name: "Primary", Steps: []models.EscalationStep{{Channel: models.NotificationChannelDiscord}}}
+	require.NoError(t, teamRepo.CreatePolicy(ctx, policy))
+
+	price := 1850.5
+	alert := &models.Alert{
+		ID:           uuid.New(),
+		UserID:       owner.ID,
+		Type:         models.AlertTypePriceBelow,
+		Status:       models.AlertStatusActive,
+		Target:       models.AlertTarget{Type: "token", Identifier: "ETH", ChainID: 1},
+		Conditions:   models.AlertConditions{Price: &price},
+		Notification: models.AlertNotification{Discord: "https://discord.com/api/webhooks/1/abc"},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+	require.NoError(t, repo.SetAlertPolicy(ctx, alert.ID, &policy.ID))
+	got, err := alertRepo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &policy.ID, got.EscalationPolicyID)
+
+	history := &models.AlertHistory{
+		ID:                 uuid.New(),
+		AlertID:            alert.ID,
+		TriggeredAt:        time.Now().UTC().Truncate(time.Microsecond),
+		ConditionsSnapshot: alert.Conditions,
+		TriggeredValue:     map[string]interface{}{"price": 1849.25},
+	}
+	require.NoError(t, alertRepo.CreateHistory(ctx, history))
+
+	escalation := &models.AlertEscalation{AlertID: alert.ID, HistoryID: history.ID, PolicyID: policy.ID, TeamID: team.ID, NextStepAt: history.TriggeredAt}
+	started, err := repo.Start(ctx, escalation)
+	require.NoError(t, err)
+	assert.True(t, started)
+	assert.Equal(t, models.EscalationStatusOpen, escalation.Status)
+	assert.Equal(t, 1849.25, escalation.TriggeredValue["price"])
+
+	// A retried trigger carries on with the escalation it has
+	retry := &models.AlertEscalation{AlertID: alert.ID, HistoryID: history.ID, PolicyID: policy.ID, TeamID: team.ID, NextStepAt: time.Now()}
+	started, err = repo.Start(ctx, retry)
+	require.NoError(t, err)
+	assert.False(t, started)
+	assert.Equal(t, escalation.ID, retry.ID)
+
+	due, err := repo.GetDue(ctx, time.Now(), 100)
+	require.NoError(t, err)
+	assert.Contains(t, escalationIDs(due), escalation.ID)
+
+	// Advancing from a step someone else already moved past is a no-op
+	advanced, err := repo.Advance(ctx, escalation.ID, 0, 1, time.Now().Add(10*time.Minute), models.EscalationStatusOpen, nil)
+	require.NoError(t, err)
+	assert.True(t, advanced)
+	advanced, err = repo.Advance(ctx, escalation.ID, 0, 1, time.Now(), models.EscalationStatusOpen, nil)
+	require.NoError(t, err)
+	assert.False(t, advanced)
+
+	acked, err := repo.Acknowledge(ctx, team.ID, escalation.ID, owner.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, acked)
+	acked, err = repo.Acknowledge(ctx, team.ID, escalation.ID, owner.ID, time.Now())
+	require.NoError(t, err)
+	assert.False(t, acked)
+
+	status := models.EscalationStatusAcknowledged
+	listed, err := repo.GetByTeam(ctx, team.ID, &status, 10)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, &owner.ID, listed[0].AcknowledgedBy)
+
+	// Deleting the policy sends the alert back to notifying only its owner
+	_, err = teamRepo.DeletePolicy(ctx, team.ID, policy.ID)
+	require.NoError(t, err)
+	got, err = alertRepo.GetByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.EscalationPolicyID)
+}
+
+func escalationIDs(escalations []*models.AlertEscalation) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(escalations))
+	for _, e := range escalations {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestTeamActionRepository(t *testing.T) {
+	ctx := context.Background()
+	teamRepo := repos.NewTeamRepository(db)
+	repo := repos.NewTeamActionRepository(db)
+	alice, bob := newUser(t), newUser(t)
+
+	team := &models.Team{Name: "Treasury", CreatedBy: alice.ID}
+	require.NoError(t, teamRepo.Create(ctx, team))
+	target := uuid.New()
+
+	action := &models.TeamPendingAction{
+		TeamID:      team.ID,
+		Action:      models.TeamActionUpdateEscalationPolicy,
+		TargetID:    target,
+		Payload:     []byte(`{"name":"Paging"}`),
+		RequestedBy: alice.ID,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, action))
+	assert.Equal(t, models.TeamActionStatusPending, action.Status)
+	err := repo.Create(ctx, &models.TeamPendingAction{TeamID: team.ID, Action: action.Action, TargetID: target, RequestedBy: bob.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, repos.ErrTeamActionPending)
+
+	// Requesters can't approve their own change
+	approved, err := repo.Approve(ctx, team.ID, action.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Nil(t, approved)
+	approved, err = repo.Approve(ctx, team.ID, action.ID, bob.ID)
+	require.NoError(t, err)
+	require.NotNil(t, approved)
+	assert.Equal(t, models.TeamActionStatusApproved, approved.Status)
+	assert.JSONEq(t, `{"name":"Paging"}`, string(approved.Payload))
+	rejected, err := repo.Reject(ctx, team.ID, action.ID, bob.ID)
+	require.NoError(t, err)
+	assert.False(t, rejected)
+
+	// A lapsed request reads as expired and doesn't block asking again
+	lapsed := &models.TeamPendingAction{TeamID: team.ID, Action: models.TeamActionRemoveMember, TargetID: bob.ID, RequestedBy: alice.ID, ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, repo.Create(ctx, lapsed))
+	got, err := repo.GetByID(ctx, lapsed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TeamActionStatusExpired, got.Status)
+	approved, err = repo.Approve(ctx, team.ID, lapsed.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Nil(t, approved)
+	again := &models.TeamPendingAction{TeamID: team.ID, Action: models.TeamActionRemoveMember, TargetID: bob.ID, RequestedBy: alice.ID, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(ctx, again))
+
+	rejected, err = repo.Reject(ctx, team.ID, again.ID, alice.ID)
+	require.NoError(t, err)
+	assert.True(t, rejected)
+	require.NoError(t, repo.MarkFailed(ctx, action.ID, "Escalation policy not found"))
+
+	actions, err := repo.GetByTeam(ctx, team.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+	assert.Equal(t, models.TeamActionStatusRejected, actions[0].Status)
+	assert.Equal(t, models.TeamActionStatusExpired, actions[1].Status)
+	assert.Equal(t, models.TeamActionStatusFailed, actions[2].Status)
+	require.NotNil(t, actions[2].Error)
+}
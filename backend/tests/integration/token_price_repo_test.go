@@ -0,0 +1,52 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenMetadataRepository_SavePrice(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewTokenMetadataRepository(db)
+
+	address := fmt.Sprintf("0x%040x", time.Now().UnixNano())
+	var tokenID uuid.UUID
+	var updatedAt time.Time
+	require.NoError(t, db.QueryRow(ctx, `
+		INSERT INTO tokens (address, chain_id, symbol, name, decimals)
+		VALUES ($1, 1, 'LIVE', 'Live Token', 18) RETURNING id, updated_at`, address).Scan(&tokenID, &updatedAt))
+
+	token, err := repo.GetToken(ctx, tokenID)
+	require.NoError(t, err)
+	assert.Nil(t, token.PriceUSD, "a token never priced has no price")
+
+	for _, price := range []float64{1.25, 1.5} {
+		savedAt, err := repo.SavePrice(ctx, tokenID, price, -2.5)
+		require.NoError(t, err)
+
+		token, err = repo.GetToken(ctx, tokenID)
+		require.NoError(t, err)
+		require.NotNil(t, token.PriceUSD)
+		assert.Equal(t, price, *token.PriceUSD)
+		assert.Equal(t, -2.5, *token.PriceChange24h)
+		require.NotNil(t, token.LastUpdated)
+		assert.True(t, savedAt.Equal(*token.LastUpdated))
+	}
+
+	// Price refreshes leave the tokens row itself alone
+	var stillUpdatedAt time.Time
+	require.NoError(t, db.QueryRow(ctx, `SELECT updated_at FROM tokens WHERE id = $1`, tokenID).Scan(&stillUpdatedAt))
+	assert.True(t, updatedAt.Equal(stillUpdatedAt))
+
+	_, err = repo.SavePrice(ctx, uuid.New(), 1, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageFlagRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewUsageFlagRepository(db)
+	user := newUser(t)
+	admin := newUser(t)
+	now := time.Now()
+
+	flag := &models.UsageFlag{
+		SubjectType:     models.UsageSubjectUser,
+		Subject:         user.ID.String(),
+		UserID:          &user.ID,
+		Reason:          models.UsageFlagAddressEnumeration,
+		Details:         map[string]interface{}{"distinct_addresses": 101},
+		RestrictedUntil: now.Add(time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, flag))
+	expired := &models.UsageFlag{
+		SubjectType:     models.UsageSubjectUser,
+		Subject:         user.ID.String(),
+		UserID:          &user.ID,
+		Reason:          models.UsageFlagRequestBurst,
+		RestrictedUntil: now.Add(-time.Minute),
+	}
+	require.NoError(t, repo.Create(ctx, expired))
+
+	active, err := repo.GetActive(ctx, now)
+	require.NoError(t, err)
+	assert.True(t, containsUsageFlag(active, flag.ID))
+	assert.False(t, containsUsageFlag(active, expired.ID))
+
+	flags, err := repo.List(ctx, false, now, 200)
+	require.NoError(t, err)
+	assert.True(t, containsUsageFlag(flags, expired.ID))
+
+	resolved, err := repo.Resolve(ctx, flag.ID, admin.ID, now)
+	require.NoError(t, err)
+	assert.True(t, resolved)
+	resolved, err = repo.Resolve(ctx, flag.ID, admin.ID, now)
+	require.NoError(t, err)
+	assert.False(t, resolved)
+
+	active, err = repo.List(ctx, true, now, 200)
+	require.NoError(t, err)
+	assert.False(t, containsUsageFlag(active, flag.ID), "resolved flags aren't active")
+}
+
+func containsUsageFlag(flags []*models.UsageFlag, id uuid.UUID) bool {
+	for _, flag := range flags {
+		if flag.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletBackfillRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletBackfillRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	none, err := repo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	queued, err := repo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	var found bool
+	for _, backfill := range queued {
+		if backfill.WalletID == wallet.ID {
+			found = true
+			assert.Equal(t, user.ID, backfill.UserID)
+			assert.Equal(t, 1, backfill.ChainID)
+		}
+	}
+	assert.True(t, found, "the new wallet is queued")
+	queued, err = repo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, queued, "wallets are queued once")
+
+	backfill, err := repo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, backfill)
+	assert.Equal(t, models.BackfillStatusPending, backfill.Status)
+	assert.Equal(t, user.ID, backfill.UserID)
+
+	require.NoError(t, repo.Start(ctx, backfill, 199))
+	assert.Equal(t, models.BackfillStatusRunning, backfill.Status)
+	require.NotNil(t, backfill.StartedAt)
+
+	block := int64(42)
+	value := "1000000000000000000"
+	tx := &models.Transaction{
+		Hash:        "0x" + uuid.NewString()[:8] + "00000000000000000000000000000000000000000000000000000000",
+		ChainID:     1,
+		FromAddress: "0x9999999999999999999999999999999999999999",
+		ToAddress:   &wallet.Address,
+		Value:       &value,
+		BlockNumber: &block,
+		Timestamp:   time.Now().UTC(),
+		Status:      models.TransactionStatusConfirmed,
+		Type:        "receive",
+	}
+	require.NoError(t, repo.SaveChunk(ctx, backfill, []*models.Transaction{tx}, 100))
+	assert.Equal(t, int64(100), backfill.NextBlock)
+	assert.Equal(t, 1, backfill.TransactionsFound)
+	assert.Equal(t, models.BackfillStatusRunning, backfill.Status)
+
+	// Finding the same transaction again, as chains ignoring block ranges return it,
+	// doesn't count it twice, but fills in the gas fee it was stored without
+	gasUsed, gasPrice, gasFeeUSD := int64(21000), "20000000000", 1.25
+	tx.GasUsed, tx.GasPrice, tx.GasFeeUSD = &gasUsed, &gasPrice, &gasFeeUSD
+	require.NoError(t, repo.SaveChunk(ctx, backfill, []*models.Transaction{tx}, 200))
+	assert.Equal(t, 1, backfill.TransactionsFound)
+	assert.Equal(t, models.BackfillStatusCompleted, backfill.Status)
+	require.NotNil(t, backfill.CompletedAt)
+
+	stored, err := repos.NewTransactionRepository(db).GetByHashes(ctx, 1, []string{tx.Hash})
+	require.NoError(t, err)
+	require.Contains(t, stored, tx.Hash)
+	require.NotNil(t, stored[tx.Hash].GasFeeUSD)
+	assert.Equal(t, gasFeeUSD, *stored[tx.Hash].GasFeeUSD)
+	assert.Equal(t, gasUsed, *stored[tx.Hash].GasUsed)
+
+	active, err := repo.GetActive(ctx, 100)
+	require.NoError(t, err)
+	for _, b := range active {
+		assert.NotEqual(t, backfill.ID, b.ID, "completed backfills aren't active")
+	}
+}
+
+func TestWalletBackfillRepositoryRecordError(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletBackfillRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 137, nil, true)
+	require.NoError(t, err)
+	_, err = repo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	backfill, err := repo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, backfill)
+
+	require.NoError(t, repo.RecordError(ctx, backfill.ID, "rate limited", 2))
+	backfill, err = repo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillStatusPending, backfill.Status)
+	require.NotNil(t, backfill.Error)
+	assert.Equal(t, "rate limited", *backfill.Error)
+
+	require.NoError(t, repo.RecordError(ctx, backfill.ID, "rate limited", 2))
+	backfill, err = repo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BackfillStatusFailed, backfill.Status)
+}
+
+func TestWalletBackfillRepositoryCopyFromPeer(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletBackfillRepository(db)
+	walletRepo := repos.NewWalletRepository(db)
+	user := newUser(t)
+	other := newUser(t)
+
+	wallet, err := walletRepo.Create(ctx, user.ID, user.Address, 8453, nil, true)
+	require.NoError(t, err)
+	_, err = repo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	backfill, err := repo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, backfill)
+
+	copied, err := repo.CopyFromPeer(ctx, backfill)
+	require.NoError(t, err)
+	assert.False(t, copied, "nobody else has imported the address yet")
+
+	require.NoError(t, repo.Start(ctx, backfill, 99))
+	block := int64(7)
+	tx := &models.Transaction{
+		Hash:        "0x" + uuid.NewString()[:8] + "11111111111111111111111111111111111111111111111111111111",
+		ChainID:     8453,
+		FromAddress: wallet.Address,
+		BlockNumber: &block,
+		Timestamp:   time.Now().UTC(),
+		Status:      models.TransactionStatusConfirmed,
+		Type:        "send",
+	}
+	require.NoError(t, repo.SaveChunk(ctx, backfill, []*models.Transaction{tx}, 100))
+	require.Equal(t, models.BackfillStatusCompleted, backfill.Status)
+
+	peer, err := walletRepo.Create(ctx, other.ID, user.Address, 8453, nil, true)
+	require.NoError(t, err)
+	_, err = repo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	peerBackfill, err := repo.GetByWalletID(ctx, peer.ID)
+	require.NoError(t, err)
+	require.NotNil(t, peerBackfill)
+
+	copied, err = repo.CopyFromPeer(ctx, peerBackfill)
+	require.NoError(t, err)
+	assert.True(t, copied)
+	assert.Equal(t, models.BackfillStatusCompleted, peerBackfill.Status)
+	require.NotNil(t, peerBackfill.TargetBlock)
+	assert.Equal(t, int64(99), *peerBackfill.TargetBlock)
+	assert.Equal(t, 1, peerBackfill.TransactionsFound)
+
+	var linked int
+	require.NoError(t, db.QueryRow(ctx, `SELECT COUNT(*) FROM user_transactions WHERE user_id = $1 AND wallet_id = $2`, other.ID, peer.ID).Scan(&linked))
+	assert.Equal(t, 1, linked, "the peer's history is linked to the new owner")
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletGroupRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletGroupRepository(db)
+	walletRepo := repos.NewWalletRepository(db)
+	user := newUser(t)
+	other := newUser(t)
+
+	mainnet, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	polygon, err := walletRepo.Create(ctx, user.ID, user.Address, 137, nil, false)
+	require.NoError(t, err)
+	foreign, err := walletRepo.Create(ctx, other.ID, other.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	// Another user's wallet is never moved into the group
+	group := &models.WalletGroup{UserID: user.ID, Name: "Long-term", WalletIDs: []uuid.UUID{mainnet.ID, foreign.ID}}
+	require.NoError(t, repo.Create(ctx, group))
+	assert.Equal(t, []uuid.UUID{mainnet.ID}, group.WalletIDs)
+
+	err = repo.Create(ctx, &models.WalletGroup{UserID: user.ID, Name: "Long-term"})
+	assert.ErrorIs(t, err, repos.ErrWalletGroupNameTaken)
+
+	// Moving a wallet to another group takes it out of the first
+	degen := &models.WalletGroup{UserID: user.ID, Name: "Degen", WalletIDs: []uuid.UUID{polygon.ID}}
+	require.NoError(t, repo.Create(ctx, degen))
+	degen.WalletIDs = []uuid.UUID{polygon.ID, mainnet.ID}
+	require.NoError(t, repo.Update(ctx, degen))
+	assert.ElementsMatch(t, []uuid.UUID{polygon.ID, mainnet.ID}, degen.WalletIDs)
+
+	groups, err := repo.GetByUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "Degen", groups[0].Name, "groups are listed by name")
+	assert.Empty(t, groups[1].WalletIDs)
+
+	wallet, err := walletRepo.GetByID(ctx, mainnet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, wallet.GroupID)
+	assert.Equal(t, degen.ID, *wallet.GroupID)
+
+	// Deleting a group ungroups its wallets
+	deleted, err := repo.Delete(ctx, degen.ID, user.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	wallet, err = walletRepo.GetByID(ctx, mainnet.ID)
+	require.NoError(t, err)
+	assert.Nil(t, wallet.GroupID)
+
+	found, err := repo.GetByID(ctx, degen.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -0,0 +1,80 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/defi-dashboard/backend/pkg/blockchain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletLabelRepository(t *testing.T) {
+	ctx := context.Background()
+	backfillRepo := repos.NewWalletBackfillRepository(db)
+	repo := repos.NewWalletLabelRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	_, err = backfillRepo.EnqueueMissing(ctx)
+	require.NoError(t, err)
+	backfill, err := backfillRepo.GetByWalletID(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, backfill)
+	require.NoError(t, backfillRepo.Start(ctx, backfill, 199))
+
+	manager := "0xc36442b4a4522e871399cd717abdd847ab11fe88"
+	var transactions []*models.Transaction
+	for i := 0; i < 3; i++ {
+		block := int64(10 + i)
+		transactions = append(transactions, &models.Transaction{
+			Hash:        fmt.Sprintf("0x%s%056d", uuid.NewString()[:8], i),
+			ChainID:     1,
+			FromAddress: wallet.Address,
+			ToAddress:   &manager,
+			BlockNumber: &block,
+			Timestamp:   time.Now().UTC(),
+			Status:      models.TransactionStatusConfirmed,
+			Type:        "send",
+			Metadata:    map[string]interface{}{"asset": "USDC"},
+		})
+	}
+	require.NoError(t, backfillRepo.SaveChunk(ctx, backfill, transactions[:2], 100))
+
+	labels, err := repo.GetByWallet(ctx, wallet.ID, 3)
+	require.NoError(t, err)
+	assert.Empty(t, labels, "too few interactions to suggest yet")
+
+	require.NoError(t, backfillRepo.SaveChunk(ctx, backfill, transactions[2:], 200))
+	labels, err = repo.GetByWallet(ctx, wallet.ID, 3)
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, blockchain.LabelUniswapLP, labels[0].Label)
+	assert.Equal(t, 3, labels[0].Interactions)
+	assert.Equal(t, models.WalletLabelSuggested, labels[0].Status)
+
+	none, err := repo.SetStatus(ctx, wallet.ID, "Unknown", models.WalletLabelAccepted)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	dismissed, err := repo.SetStatus(ctx, wallet.ID, blockchain.LabelUniswapLP, models.WalletLabelDismissed)
+	require.NoError(t, err)
+	require.NotNil(t, dismissed)
+	labels, err = repo.GetByWallet(ctx, wallet.ID, 3)
+	require.NoError(t, err)
+	assert.Empty(t, labels, "dismissed labels aren't suggested again")
+
+	_, err = repo.SetStatus(ctx, wallet.ID, blockchain.LabelUniswapLP, models.WalletLabelAccepted)
+	require.NoError(t, err)
+	labels, err = repo.GetByWallet(ctx, wallet.ID, 100)
+	require.NoError(t, err)
+	require.Len(t, labels, 1, "accepted labels are kept whatever their interactions")
+}
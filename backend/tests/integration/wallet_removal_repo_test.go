@@ -0,0 +1,139 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runWalletRemoval runs every step of the removal and completes it, as the job does
+func runWalletRemoval(t *testing.T, repo repos.WalletRemovalRepository, removal *models.WalletRemoval) {
+	ctx := context.Background()
+	require.NoError(t, repo.Start(ctx, removal, time.Now()))
+	for step := removal.NextStep(); step != ""; step = removal.NextStep() {
+		rows, err := repo.RunStep(ctx, removal, step)
+		require.NoError(t, err, step)
+		removal.RowsDeleted += rows
+		removal.StepsDone++
+		require.NoError(t, repo.SaveProgress(ctx, removal))
+	}
+	now := time.Now()
+	removal.Status = models.WalletRemovalCompleted
+	removal.CompletedAt = &now
+	require.NoError(t, repo.SaveProgress(ctx, removal))
+}
+
+func TestWalletRemovalRepositoryKeepHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletRemovalRepository(db)
+	walletRepo := repos.NewWalletRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+	user := newUser(t)
+
+	mainnet, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	_, err = walletRepo.Create(ctx, user.ID, user.Address, 10, nil, false)
+	require.NoError(t, err)
+
+	newAddressAlert := func(chainID int) *models.Alert {
+		alert := &models.Alert{
+			ID:     uuid.New(),
+			UserID: user.ID,
+			Type:   models.AlertTypeLargeTransfer,
+			Status: models.AlertStatusActive,
+			Target: models.AlertTarget{Type: "address", Identifier: user.Address, ChainID: chainID},
+		}
+		require.NoError(t, alertRepo.Create(ctx, alert))
+		return alert
+	}
+	onMainnet := newAddressAlert(1)
+	anyChain := newAddressAlert(0)
+
+	removal := &models.WalletRemoval{WalletID: mainnet.ID, UserID: user.ID, Retention: models.WalletRemovalKeepHistory}
+	assert.ErrorIs(t, repo.Create(ctx, &models.WalletRemoval{WalletID: mainnet.ID, UserID: newUser(t).ID, Retention: models.WalletRemovalPurge}),
+		repos.ErrWalletNotFound, "only the owner removes a wallet")
+	require.NoError(t, repo.Create(ctx, removal))
+	assert.Equal(t, models.WalletRemovalPending, removal.Status)
+	assert.Equal(t, len(models.WalletRemovalSteps(models.WalletRemovalKeepHistory)), removal.StepsTotal)
+	assert.Equal(t, 1, removal.ChainID)
+
+	// The wallet is gone at once
+	_, err = walletRepo.GetByID(ctx, mainnet.ID)
+	assert.ErrorIs(t, err, repos.ErrWalletNotFound)
+	wallets, err := walletRepo.GetByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, walletIDs(wallets), mainnet.ID)
+	assert.ErrorIs(t, repo.Create(ctx, &models.WalletRemoval{WalletID: mainnet.ID, UserID: user.ID, Retention: models.WalletRemovalPurge}),
+		repos.ErrWalletNotFound, "a wallet is removed once")
+	_, err = walletRepo.Create(ctx, user.ID, user.Address, 1, nil, false)
+	assert.ErrorIs(t, err, repos.ErrWalletAlreadyTracked, "a wallet can't be added back while it's being removed")
+
+	active, err := repo.GetNextActive(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+
+	runWalletRemoval(t, repo, removal)
+	got, err := repo.GetByID(ctx, removal.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletRemovalCompleted, got.Status)
+	assert.Equal(t, got.StepsTotal, got.StepsDone)
+	none, err := repo.GetByID(ctx, removal.ID, uuid.New())
+	require.NoError(t, err)
+	assert.Nil(t, none, "users only see their own removals")
+
+	alert, err := alertRepo.GetByID(ctx, onMainnet.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertStatusDisabled, alert.Status)
+	alert, err = alertRepo.GetByID(ctx, anyChain.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.AlertStatusActive, alert.Status, "the address is still tracked on another chain")
+
+	// The history kept comes back with the wallet
+	shared, err := walletRepo.GetByAddress(ctx, user.Address, 1)
+	require.NoError(t, err)
+	assert.Equal(t, mainnet.ID, shared.ID, "the removed wallet still holds the address's PnL lots")
+	restored, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, mainnet.ID, restored.ID)
+}
+
+func TestWalletRemovalRepositoryPurge(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletRemovalRepository(db)
+	walletRepo := repos.NewWalletRepository(db)
+	alertRepo := repos.NewAlertRepository(db, nil)
+	user := newUser(t)
+
+	wallet, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	alert := &models.Alert{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Type:   models.AlertTypeLargeTransfer,
+		Status: models.AlertStatusActive,
+		Target: models.AlertTarget{Type: "address", Identifier: user.Address, ChainID: 1},
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	removal := &models.WalletRemoval{WalletID: wallet.ID, UserID: user.ID, Retention: models.WalletRemovalPurge}
+	require.NoError(t, repo.Create(ctx, removal))
+	runWalletRemoval(t, repo, removal)
+	assert.Positive(t, removal.RowsDeleted)
+
+	_, err = walletRepo.GetByAddress(ctx, user.Address, 1)
+	assert.ErrorIs(t, err, repos.ErrWalletNotFound, "a purged wallet is deleted")
+	_, err = alertRepo.GetByID(ctx, alert.ID)
+	assert.Error(t, err, "alerts on a purged wallet are deleted")
+
+	readded, err := walletRepo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	assert.NotEqual(t, wallet.ID, readded.ID)
+}
@@ -0,0 +1,159 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletRepository(db)
+	user := newUser(t)
+
+	label := "Cold storage"
+	main, err := repo.Create(ctx, user.ID, user.Address, 1, &label, true)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, main.UserID)
+	assert.False(t, main.IsTestnet)
+	assert.False(t, main.CreatedAt.IsZero())
+
+	amoy, err := repo.Create(ctx, user.ID, user.Address, 80002, nil, false)
+	require.NoError(t, err)
+	assert.True(t, amoy.IsTestnet)
+	assert.Nil(t, amoy.Label)
+
+	got, err := repo.GetByID(ctx, main.ID)
+	require.NoError(t, err)
+	assert.Equal(t, main, got)
+
+	// Addresses match regardless of checksum casing
+	got, err = repo.GetByAddress(ctx, "0x"+strings.ToUpper(user.Address[2:]), 1)
+	require.NoError(t, err)
+	assert.Equal(t, main.ID, got.ID)
+	_, err = repo.GetByAddress(ctx, user.Address, 10)
+	assert.ErrorIs(t, err, repos.ErrWalletNotFound)
+
+	renamed := "Hardware wallet"
+	got, err = repo.Update(ctx, main.ID, user.ID, &renamed)
+	require.NoError(t, err)
+	assert.Equal(t, renamed, *got.Label)
+
+	require.NoError(t, repo.SetPrimary(ctx, user.ID, amoy.ID))
+	wallets, err := repo.GetByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, wallets, 2)
+	assert.Equal(t, amoy.ID, wallets[0].ID, "primary wallet comes first")
+	assert.True(t, wallets[0].IsPrimary)
+	assert.False(t, wallets[1].IsPrimary)
+
+	// Another user's wallet can't be touched
+	other := newUser(t)
+	assert.ErrorIs(t, repo.SetPrimary(ctx, other.ID, main.ID), repos.ErrWalletNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, main.ID, other.ID), repos.ErrWalletNotFound)
+
+	require.NoError(t, repo.Delete(ctx, main.ID, user.ID))
+	_, err = repo.GetByID(ctx, main.ID)
+	assert.ErrorIs(t, err, repos.ErrWalletNotFound)
+}
+
+func TestWalletRepositoryReadsFixtures(t *testing.T) {
+	wallets, err := repos.NewWalletRepository(db).GetByUserID(context.Background(), fixtures.ID("user:alice"))
+	require.NoError(t, err)
+
+	expected := 0
+	for _, wallet := range seed.Wallets {
+		if wallet.UserID == fixtures.ID("user:alice") {
+			expected++
+		}
+	}
+	require.Len(t, wallets, expected)
+	assert.Equal(t, fixtures.ID("wallet:alice:1"), wallets[0].ID)
+
+	for _, wallet := range wallets {
+		assert.Equal(t, wallet.ChainID == 80002, wallet.IsTestnet, "chain %d", wallet.ChainID)
+		assert.Nil(t, wallet.Chain, "EVM wallets have no CAIP chain reference")
+	}
+}
+
+func TestWalletRepositorySetVisibility(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletRepository(db)
+	user := newUser(t)
+	other := newUser(t)
+
+	wallet, err := repo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletVisibilityVisible, wallet.Visibility, "wallets start out visible")
+
+	private, err := repo.SetVisibility(ctx, wallet.ID, user.ID, models.WalletVisibilityPrivate)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletVisibilityPrivate, private.Visibility)
+
+	_, err = repo.SetVisibility(ctx, wallet.ID, other.ID, models.WalletVisibilityVisible)
+	assert.ErrorIs(t, err, repos.ErrWalletNotFound, "only the owner changes visibility")
+}
+
+func TestWalletRepositoryTracksAddressPerUser(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletRepository(db)
+	user := newUser(t)
+	other := newUser(t)
+
+	first, err := repo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, user.ID, user.Address, 1, nil, false)
+	assert.ErrorIs(t, err, repos.ErrWalletAlreadyTracked)
+
+	_, err = repo.Create(ctx, user.ID, user.Address, 10, nil, false)
+	require.NoError(t, err, "the same address can be added on another chain")
+
+	_, err = repo.Create(ctx, other.ID, user.Address, 1, nil, true)
+	require.NoError(t, err, "another user can track the same address")
+
+	shared, err := repo.GetByAddress(ctx, user.Address, 1)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, shared.ID, "the first wallet added holds the address's shared data")
+}
+
+func TestWalletRepositoryAccountType(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletRepository(db)
+	user := newUser(t)
+
+	wallet, err := repo.Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+	assert.Nil(t, wallet.AccountType)
+
+	pending, err := repo.GetUndetectedAccounts(ctx, 1000)
+	require.NoError(t, err)
+	assert.Contains(t, walletIDs(pending), wallet.ID)
+
+	require.NoError(t, repo.SetAccountType(ctx, wallet.ID, models.WalletAccountSafe))
+	saved, err := repo.GetByID(ctx, wallet.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved.AccountType)
+	assert.Equal(t, models.WalletAccountSafe, *saved.AccountType)
+	assert.True(t, saved.IsContractAccount())
+
+	pending, err = repo.GetUndetectedAccounts(ctx, 1000)
+	require.NoError(t, err)
+	assert.NotContains(t, walletIDs(pending), wallet.ID)
+}
+
+func walletIDs(wallets []*models.Wallet) []uuid.UUID {
+	ids := make([]uuid.UUID, len(wallets))
+	for i, wallet := range wallets {
+		ids[i] = wallet.ID
+	}
+	return ids
+}
@@ -0,0 +1,72 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHoldingSnapshots(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWalletValuationRepository(db)
+	balanceRepo := repos.NewBalanceRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	usdcValue := 2500.0
+	usdc := &models.Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC", Name: "USD Coin", Decimals: 6}
+	link := &models.Token{Address: "0x514910771af9ca656af840dff83e8264ecf986ca", Symbol: "LINK", Name: "Chainlink", Decimals: 18}
+	_, err = balanceRepo.ReplaceWalletBalances(ctx, wallet.ID, 1, []*models.Balance{
+		{Token: usdc, Balance: "2500000000", BalanceUSD: &usdcValue},
+	})
+	require.NoError(t, err)
+
+	recorded, err := repo.RecordHoldings(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, recorded)
+	latest, err := repo.GetLatestHoldingsRun(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+
+	_, err = balanceRepo.ReplaceWalletBalances(ctx, wallet.ID, 1, []*models.Balance{
+		{Token: link, Balance: "12500000000000000000"},
+	})
+	require.NoError(t, err)
+
+	baselineAt, holdings, err := repo.GetHoldingsAt(ctx, []uuid.UUID{wallet.ID}, time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, baselineAt)
+	assert.True(t, latest.Equal(*baselineAt))
+	require.Len(t, holdings, 1)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", holdings[0].TokenAddress)
+	assert.Equal(t, "2500000000", holdings[0].Balance)
+	assert.Equal(t, 2500.0, holdings[0].Amount)
+	assert.Greater(t, holdings[0].ValueUSD, 0.0)
+
+	current, err := repo.GetCurrentHoldings(ctx, []uuid.UUID{wallet.ID})
+	require.NoError(t, err)
+	require.Len(t, current, 1)
+	assert.Equal(t, "LINK", current[0].Symbol)
+	assert.Equal(t, 12.5, current[0].Amount)
+
+	// Nothing was snapshotted a year ago
+	baselineAt, _, err = repo.GetHoldingsAt(ctx, []uuid.UUID{wallet.ID}, time.Now().Add(-365*24*time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, baselineAt)
+
+	pruned, err := repo.DeleteHoldingsOlderThan(ctx, latest.Add(time.Microsecond))
+	require.NoError(t, err)
+	assert.Positive(t, pruned)
+	baselineAt, _, err = repo.GetHoldingsAt(ctx, []uuid.UUID{wallet.ID}, time.Now())
+	require.NoError(t, err)
+	assert.Nil(t, baselineAt)
+}
\ No newline at end of file
@@ -0,0 +1,35 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookVerificationRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewWebhookVerificationRepository(db)
+	user := newUser(t)
+	other := newUser(t)
+	hash := "5d41402abc4b2a76b9719d911017c592b5d41402abc4b2a76b9719d911017c59"
+
+	verified, err := repo.IsVerified(ctx, user.ID, hash)
+	require.NoError(t, err)
+	assert.False(t, verified)
+
+	require.NoError(t, repo.MarkVerified(ctx, user.ID, hash, time.Now()))
+	require.NoError(t, repo.MarkVerified(ctx, user.ID, hash, time.Now()), "verifying again refreshes it")
+
+	verified, err = repo.IsVerified(ctx, user.ID, hash)
+	require.NoError(t, err)
+	assert.True(t, verified)
+	verified, err = repo.IsVerified(ctx, other.ID, hash)
+	require.NoError(t, err)
+	assert.False(t, verified)
+}
@@ -0,0 +1,123 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYieldPoolMigrationRepositoryCarriesPositions(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewYieldPoolMigrationRepository(db)
+	poolRepo := repos.NewYieldPoolRepository(db)
+	positionRepo := repos.NewYieldPositionRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	// A v2 pool the protocol migrated to a v3 one
+	suffix := uuid.NewString()
+	pools := map[string]*models.YieldPool{}
+	for _, poolID := range []string{"v2-" + suffix, "v3-" + suffix} {
+		require.NoError(t, poolRepo.Upsert(ctx, &models.YieldPool{
+			PoolID:   poolID,
+			Protocol: &models.Protocol{Name: "Integration Protocol"},
+			PoolName: "USDC-WETH " + poolID[:2],
+			Chain:    "ethereum",
+			Symbol:   "USDC-WETH",
+			IsActive: true,
+		}))
+		pool, err := poolRepo.GetByPoolID(ctx, poolID)
+		require.NoError(t, err)
+		pools[poolID[:2]] = pool
+	}
+	v2, v3 := pools["v2"], pools["v3"]
+
+	assert.ErrorIs(t, repo.SetSuccessor(ctx, v2.PoolID, "missing-"+suffix, time.Now()), repos.ErrYieldPoolNotFound)
+	require.NoError(t, repo.SetSuccessor(ctx, v2.PoolID, v3.PoolID, time.Now()))
+	assert.ErrorIs(t, repo.SetSuccessor(ctx, v3.PoolID, v2.PoolID, time.Now()), repos.ErrYieldPoolMigrationCycle)
+
+	deprecated, err := poolRepo.GetByID(ctx, v2.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deprecated.SuccessorPoolID)
+	assert.Equal(t, v3.PoolID, *deprecated.SuccessorPoolID)
+	assert.NotNil(t, deprecated.DeprecatedAt)
+
+	entry, rewards, realized := 1000.0, 15.0, 4.0
+	entryTime := fixtureTime.AddDate(0, -6, 0)
+	old, err := positionRepo.Create(ctx, &models.YieldPosition{
+		UserID: user.ID, WalletID: wallet.ID, PoolID: v2.ID, ProtocolID: v2.ProtocolID, ChainID: 1,
+		BalanceRaw: "1000", EntryPriceUSD: &entry, EntryTime: entryTime,
+	})
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `UPDATE yield_positions SET total_rewards_usd = $2, realized_pnl_usd = $3 WHERE id = $1`,
+		old.ID, rewards, realized)
+	require.NoError(t, err)
+
+	// Its holder is told once
+	notices, err := repo.GetPendingNotices(ctx, 1000)
+	require.NoError(t, err)
+	var notice *models.YieldPoolMigrationNotice
+	for _, n := range notices {
+		if n.PositionID == old.ID {
+			notice = n
+		}
+	}
+	require.NotNil(t, notice)
+	assert.Equal(t, v3.ID, notice.SuccessorID)
+	assert.Equal(t, v3.PoolID, notice.SuccessorPoolID)
+	require.NoError(t, repo.RecordNotice(ctx, notice, false, time.Now()))
+	require.NoError(t, repo.RecordNotice(ctx, notice, false, time.Now()))
+	notices, err = repo.GetPendingNotices(ctx, 1000)
+	require.NoError(t, err)
+	for _, n := range notices {
+		assert.NotEqual(t, old.ID, n.PositionID, "holders are told once")
+	}
+
+	// The position opened in the v3 pool carries on the v2 one
+	newEntry := 1100.0
+	opened, err := positionRepo.Create(ctx, &models.YieldPosition{
+		UserID: user.ID, WalletID: wallet.ID, PoolID: v3.ID, ProtocolID: v3.ProtocolID, ChainID: 1,
+		BalanceRaw: "1000", EntryPriceUSD: &newEntry, EntryTime: time.Now(),
+	})
+	require.NoError(t, err)
+	from, err := repo.CarryOver(ctx, opened.ID)
+	require.NoError(t, err)
+	require.NotNil(t, from)
+	assert.Equal(t, old.ID, *from)
+
+	carried, err := positionRepo.GetByID(ctx, opened.ID)
+	require.NoError(t, err)
+	require.NotNil(t, carried.MigratedFromPositionID)
+	assert.Equal(t, old.ID, *carried.MigratedFromPositionID)
+	assert.True(t, entryTime.Equal(carried.EntryTime))
+	require.NotNil(t, carried.EntryPriceUSD)
+	assert.InDelta(t, entry, *carried.EntryPriceUSD, 0.0001)
+	require.NotNil(t, carried.TotalRewardsUSD)
+	assert.InDelta(t, rewards, *carried.TotalRewardsUSD, 0.0001)
+	require.NotNil(t, carried.RealizedPnLUSD)
+	assert.InDelta(t, realized, *carried.RealizedPnLUSD, 0.0001)
+
+	closed, err := positionRepo.GetByID(ctx, old.ID)
+	require.NoError(t, err)
+	assert.False(t, closed.IsActive)
+
+	// A position is carried on once
+	from, err = repo.CarryOver(ctx, opened.ID)
+	require.NoError(t, err)
+	assert.Nil(t, from)
+
+	require.NoError(t, repo.ClearSuccessor(ctx, v2.PoolID))
+	restored, err := poolRepo.GetByID(ctx, v2.ID)
+	require.NoError(t, err)
+	assert.Nil(t, restored.SuccessorPoolID)
+	assert.Nil(t, restored.DeprecatedAt)
+}
@@ -0,0 +1,240 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/defi-dashboard/backend/internal/fixtures"
+	"github.com/defi-dashboard/backend/internal/models"
+	"github.com/defi-dashboard/backend/internal/repos"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYieldPoolRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewYieldPoolRepository(db)
+
+	chainID := 42161
+	tvl, apy, apyBase, apyReward := 52_000_000.0, 7.25, 5.0, 2.25
+	address := "0x4c8d6e3a1f8a7b3a4b1c2d3e4f5a6b7c8d9e0f11"
+	pool := &models.YieldPool{
+		PoolID:         "integration-" + uuid.NewString(),
+		Protocol:       &models.Protocol{Name: "Integration Protocol"},
+		PoolName:       "WETH-ARB",
+		Chain:          "arbitrum",
+		ChainID:        &chainID,
+		PoolAddress:    &address,
+		Symbol:         "WETH-ARB",
+		TokenAddresses: []string{"0x82aF49447D8a07e3bd95BD0d56f35241523fBab1", "0x912CE59144191C1204E64559FE8253a0e49E6548"},
+		TVLUSD:         &tvl,
+		APY:            &apy,
+		APYBase:        &apyBase,
+		APYReward:      &apyReward,
+		RiskLevel:      "high",
+		IsActive:       true,
+		Metadata:       map[string]interface{}{"feeTier": 3000.0, "rewardTokens": []interface{}{"ARB"}},
+	}
+	require.NoError(t, repo.Upsert(ctx, pool))
+
+	got, err := repo.GetByPoolID(ctx, pool.PoolID)
+	require.NoError(t, err)
+	assert.Equal(t, pool.TokenAddresses, got.TokenAddresses)
+	assert.Equal(t, pool.Metadata, got.Metadata)
+	assert.Equal(t, &chainID, got.ChainID)
+	assert.InDelta(t, tvl, *got.TVLUSD, 0.01)
+	assert.InDelta(t, apy, *got.APY, 0.0001)
+
+	require.NoError(t, repo.UpdateAPY(ctx, pool.PoolID, 9.5, 7, 2.5))
+	byID, err := repo.GetByID(ctx, got.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 9.5, *byID.APY, 0.0001)
+
+	pools, err := repo.GetByChain(ctx, chainID)
+	require.NoError(t, err)
+	require.NotEmpty(t, pools)
+	var found *models.YieldPool
+	for _, p := range pools {
+		if p.ID == got.ID {
+			found = p
+		}
+	}
+	require.NotNil(t, found, "GetByChain returns the pool")
+	assert.Equal(t, pool.TokenAddresses, found.TokenAddresses)
+
+	require.NoError(t, repo.Deactivate(ctx, pool.PoolID))
+	pools, err = repo.GetByChain(ctx, chainID)
+	require.NoError(t, err)
+	for _, p := range pools {
+		assert.NotEqual(t, got.ID, p.ID, "inactive pools are left out")
+	}
+}
+
+func TestYieldPositionRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewYieldPositionRepository(db)
+	user := newUser(t)
+	wallet, err := repos.NewWalletRepository(db).Create(ctx, user.ID, user.Address, 1, nil, true)
+	require.NoError(t, err)
+
+	pool, err := repos.NewYieldPoolRepository(db).GetByPoolID(ctx, "fixture-aave-v3-usdc-ethereum")
+	require.NoError(t, err)
+
+	balanceUSD, entryUSD, currentUSD := 2500.0, 2400.0, 2500.0
+	usdc := fixtures.ID("token:1:USDC")
+	hash := fixtures.Hash("integration:aave-supply")
+	position := &models.YieldPosition{
+		UserID:               user.ID,
+		WalletID:             wallet.ID,
+		PoolID:               pool.ID,
+		ProtocolID:           pool.ProtocolID,
+		ChainID:              1,
+		BalanceRaw:           "2500000000",
+		BalanceUSD:           &balanceUSD,
+		BalanceTokens:        []models.TokenBalance{{TokenID: usdc, Balance: "2500000000", BalanceUSD: &balanceUSD}},
+		EntryPriceUSD:        &entryUSD,
+		EntryTransactionHash: &hash,
+		EntryTime:            fixtureTime.AddDate(0, 0, -30),
+		CurrentValueUSD:      &currentUSD,
+		Metadata:             map[string]interface{}{"aToken": "aEthUSDC"},
+	}
+	created, err := repo.Create(ctx, position)
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, created.ID)
+
+	got, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, position.BalanceTokens, got.BalanceTokens)
+	assert.Equal(t, position.Metadata, got.Metadata)
+	assert.True(t, position.EntryTime.Equal(got.EntryTime))
+	assert.True(t, got.IsActive)
+
+	rewardUSD := 12.5
+	pending := []models.RewardInfo{{TokenID: usdc, Amount: "12500000", AmountUSD: &rewardUSD}}
+	require.NoError(t, repo.UpdateRewards(ctx, created.ID, pending, []models.RewardInfo{}, rewardUSD))
+	require.NoError(t, repo.UpdateAllPnL(ctx))
+
+	positions, err := repo.GetByWallet(ctx, wallet.ID, true)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, pending, positions[0].PendingRewards)
+	assert.Empty(t, positions[0].ClaimedRewards)
+	require.NotNil(t, positions[0].UnrealizedPnLUSD)
+	assert.InDelta(t, 100, *positions[0].UnrealizedPnLUSD, 0.0001)
+	require.NotNil(t, positions[0].Pool)
+	assert.Equal(t, pool.PoolName, positions[0].Pool.PoolName)
+
+	positions, err = repo.GetUserPositionsWithPools(ctx, user.ID, repos.PositionFilters{})
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	require.NotNil(t, positions[0].Pool)
+	assert.Equal(t, pool.PoolID, positions[0].Pool.PoolID)
+	require.NotNil(t, positions[0].Protocol)
+	assert.Equal(t, "aave-v3", positions[0].Protocol.Slug)
+
+	require.NoError(t, repo.Close(ctx, created.ID, 87.5))
+	positions, err = repo.GetByWallet(ctx, wallet.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+
+	summary, err := repo.GetUserSummary(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, summary.ActivePositions)
+	assert.InDelta(t, 187.5, summary.TotalPnLUSD, 0.0001)
+}
+
+func TestYieldPositionRepositoryReadsFixtures(t *testing.T) {
+	repo := repos.NewYieldPositionRepository(db)
+	active := true
+
+	positions, err := repo.GetUserPositionsWithPools(context.Background(), fixtures.ID("user:alice"), repos.PositionFilters{IsActive: &active})
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+	// Ordered by current value
+	assert.Equal(t, fixtures.ID("position:alice:aave-v3-usdc"), positions[0].ID)
+	assert.Equal(t, fixtures.ID("position:alice:lido-steth"), positions[1].ID)
+	for _, position := range positions {
+		require.NotNil(t, position.Pool)
+		require.NotNil(t, position.PnLPercentage)
+		assert.True(t, position.EntryTime.Before(fixtureTime))
+	}
+
+	top, err := repo.GetTopByValue(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	require.NotNil(t, top[0].User)
+	assert.NotEmpty(t, top[0].User.Address)
+}
+
+func TestYieldPoolRepositorySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := repos.NewYieldPoolRepository(db)
+
+	// Unique protocol name so the fixtures' pools don't match the text search
+	protocol := "Searchtest" + uuid.NewString()[:8]
+	newPool := func(name, symbol string, chainID int, apy, tvl float64, risk string, stable bool) {
+		pool := &models.YieldPool{
+			PoolID:     "search-" + uuid.NewString(),
+			Protocol:   &models.Protocol{Name: protocol},
+			PoolName:   name,
+			Chain:      "ethereum",
+			ChainID:    &chainID,
+			Symbol:     symbol,
+			TVLUSD:     &tvl,
+			APY:        &apy,
+			RiskLevel:  risk,
+			IsActive:   true,
+			StableCoin: stable,
+		}
+		require.NoError(t, repo.Upsert(ctx, pool))
+	}
+	newPool("USDC Lending", "USDC", 1, 4.5, 900_000_000, "low", true)
+	newPool("WETH-USDC LP", "WETH-USDC", 137, 18, 40_000_000, "medium", false)
+	newPool("PEPE Farm", "PEPE-WETH", 42161, 240, 1_500_000, "high", false)
+
+	names := func(pools []*models.YieldPool) []string {
+		var out []string
+		for _, p := range pools {
+			out = append(out, p.PoolName)
+		}
+		return out
+	}
+
+	pools, total, err := repo.Search(ctx, repos.YieldPoolSearch{Text: protocol, SortBy: "tvl", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"USDC Lending", "WETH-USDC LP", "PEPE Farm"}, names(pools))
+
+	// Symbol parts and prefixes match
+	pools, _, err = repo.Search(ctx, repos.YieldPoolSearch{Text: protocol + " weth", SortBy: "apy", SortAsc: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"WETH-USDC LP", "PEPE Farm"}, names(pools))
+
+	minAPY, maxTVL := 5.0, 100_000_000.0
+	pools, total, err = repo.Search(ctx, repos.YieldPoolSearch{
+		Text:       protocol,
+		ChainIDs:   []int{137, 42161},
+		RiskLevels: []string{"medium", "high"},
+		MinAPY:     &minAPY,
+		MaxTVL:     &maxTVL,
+		SortBy:     "risk",
+		Limit:      1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total, "the total ignores the page size")
+	assert.Equal(t, []string{"PEPE Farm"}, names(pools))
+
+	pools, _, err = repo.Search(ctx, repos.YieldPoolSearch{Text: protocol, StablecoinOnly: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"USDC Lending"}, names(pools))
+
+	// Only pools first seen after CreatedAfter match
+	later := time.Now().Add(time.Hour)
+	_, total, err = repo.Search(ctx, repos.YieldPoolSearch{Text: protocol, CreatedAfter: &later, Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
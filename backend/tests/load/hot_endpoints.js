@@ -0,0 +1,148 @@
+// Load test for the API's hot endpoints: portfolio balances and history, swap quotes
+// and the alerts list, called as the seeded demo users against a local stack.
+//
+// Each endpoint's p95 and p99 latency must stay within its recorded baseline plus
+// TOLERANCE (25% by default), and fewer than 1% of its requests may fail, or k6 exits
+// non-zero. With RECORD_BASELINE=1 the run writes its latencies to baselines.json
+// instead of checking them. See "Load tests" in the README.
+import http from 'k6/http';
+import { check } from 'k6';
+import crypto from 'k6/crypto';
+import encoding from 'k6/encoding';
+
+const BASE_URL = __ENV.BASE_URL || 'http://localhost:3000';
+const JWT_SECRET = __ENV.JWT_SECRET || 'your-secret-key-change-this';
+const TOLERANCE = parseFloat(__ENV.TOLERANCE || '0.25');
+const RECORD = __ENV.RECORD_BASELINE === '1';
+
+// The demo users from internal/fixtures, loaded by make seed
+const USERS = [
+  '0xa11ce00000000000000000000000000000000001',
+  '0xb0b0000000000000000000000000000000000002',
+  '0xca40100000000000000000000000000000000003',
+];
+
+const WETH = '0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2';
+const USDC = '0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48';
+
+const ENDPOINTS = ['portfolio_balances', 'portfolio_history', 'swap_quote', 'alerts_list'];
+
+const baselines = JSON.parse(open('./baselines.json')).endpoints;
+
+// k6 only reports per-endpoint latencies for metrics with thresholds, so when recording
+// each endpoint gets one that always passes
+const thresholds = {};
+for (const name of ENDPOINTS) {
+  const baseline = baselines[name];
+  if (RECORD) {
+    thresholds[`http_req_duration{endpoint:${name}}`] = ['max>=0'];
+    thresholds[`http_req_failed{endpoint:${name}}`] = ['rate>=0'];
+    continue;
+  }
+  if (!baseline) {
+    throw new Error(`no baseline recorded for ${name}`);
+  }
+  thresholds[`http_req_duration{endpoint:${name}}`] = [
+    `p(95)<${Math.ceil(baseline.p95_ms * (1 + TOLERANCE))}`,
+    `p(99)<${Math.ceil(baseline.p99_ms * (1 + TOLERANCE))}`,
+  ];
+  thresholds[`http_req_failed{endpoint:${name}}`] = ['rate<0.01'];
+}
+
+export const options = {
+  scenarios: {
+    hot_endpoints: {
+      executor: 'ramping-vus',
+      startVUs: 0,
+      stages: [
+        { duration: __ENV.RAMP || '30s', target: parseInt(__ENV.VUS || '20', 10) },
+        { duration: __ENV.DURATION || '2m', target: parseInt(__ENV.VUS || '20', 10) },
+        { duration: '10s', target: 0 },
+      ],
+      gracefulRampDown: '10s',
+    },
+  },
+  thresholds,
+  summaryTrendStats: ['avg', 'med', 'p(95)', 'p(99)', 'max'],
+};
+
+// token signs a session token for address the way the API does, so the test doesn't
+// have to go through a wallet signature
+function token(address) {
+  const b64 = (obj) => encoding.b64encode(JSON.stringify(obj), 'rawurl');
+  const now = Math.floor(Date.now() / 1000);
+  const unsigned = `${b64({ alg: 'HS256', typ: 'JWT' })}.${b64({ address, iat: now, exp: now + 3600 })}`;
+  return `${unsigned}.${crypto.hmac('sha256', JWT_SECRET, unsigned, 'base64rawurl')}`;
+}
+
+export function setup() {
+  const tokens = {};
+  for (const address of USERS) {
+    tokens[address] = token(address);
+    const res = http.get(`${BASE_URL}/api/v1/alerts`, { headers: { Authorization: `Bearer ${tokens[address]}` } });
+    if (res.status !== 200) {
+      throw new Error(`GET /api/v1/alerts as ${address} returned ${res.status}: is the stack up and seeded (make seed)?`);
+    }
+  }
+  return { tokens };
+}
+
+export default function (data) {
+  const address = USERS[(__VU + __ITER) % USERS.length];
+  const headers = { Authorization: `Bearer ${data.tokens[address]}`, 'Content-Type': 'application/json' };
+  const get = (name, path) => http.get(`${BASE_URL}${path}`, { headers, tags: { endpoint: name } });
+
+  check(get('portfolio_balances', `/api/v1/portfolio/${address}/balances`), { 'balances 200': (r) => r.status === 200 });
+  check(get('portfolio_history', `/api/v1/portfolio/${address}/history?period=1m`), { 'history 200': (r) => r.status === 200 });
+  check(get('alerts_list', '/api/v1/alerts'), { 'alerts 200': (r) => r.status === 200 });
+
+  const quote = http.post(`${BASE_URL}/api/v1/swap/quote`, JSON.stringify({
+    chainId: 1,
+    fromToken: WETH,
+    toToken: USDC,
+    fromAmount: '1000000000000000000',
+    userAddress: address,
+  }), { headers, tags: { endpoint: 'swap_quote' } });
+  check(quote, { 'quote 200': (r) => r.status === 200 });
+}
+
+// handleSummary prints k6's usual summary and, when recording, the endpoints'
+// latencies as the new baselines
+export function handleSummary(data) {
+  const out = { stdout: textSummary(data) };
+  if (RECORD) {
+    const endpoints = {};
+    for (const name of ENDPOINTS) {
+      const metric = data.metrics[`http_req_duration{endpoint:${name}}`];
+      if (!metric) {
+        throw new Error(`no latencies recorded for ${name}`);
+      }
+      endpoints[name] = {
+        p95_ms: Math.round(metric.values['p(95)']),
+        p99_ms: Math.round(metric.values['p(99)']),
+      };
+    }
+    out['baselines.json'] = JSON.stringify({
+      source: 'recorded',
+      recorded_at: new Date().toISOString().slice(0, 10),
+      vus: parseInt(__ENV.VUS || '20', 10),
+      endpoints,
+    }, null, 2) + '\n';
+  }
+  return out;
+}
+
+function textSummary(data) {
+  const lines = [];
+  for (const name of ENDPOINTS) {
+    const metric = data.metrics[`http_req_duration{endpoint:${name}}`];
+    const failed = data.metrics[`http_req_failed{endpoint:${name}}`];
+    if (!metric) {
+      continue;
+    }
+    const v = metric.values;
+    lines.push(`${name.padEnd(20)} p95=${v['p(95)'].toFixed(1)}ms p99=${v['p(99)'].toFixed(1)}ms max=${v.max.toFixed(1)}ms` +
+      (failed ? ` failed=${(failed.values.rate * 100).toFixed(2)}%` : ''));
+  }
+  return lines.join('\n') + '\n';
+}
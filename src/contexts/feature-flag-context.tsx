@@ -93,6 +93,10 @@ export function FeatureFlagProvider({ children }: FeatureFlagProviderProps) {
           if (banner.dismissible && dismissedBanners.includes(banner.id)) return false;
           return true;
         });
+        const statusBanner = await fetchStatusBanner();
+        if (statusBanner && !dismissedBanners.includes(statusBanner.id)) {
+          activeBanners.unshift(statusBanner);
+        }
         setBanners(activeBanners);
       }
     } catch (error) {
@@ -100,6 +104,20 @@ export function FeatureFlagProvider({ children }: FeatureFlagProviderProps) {
     }
   };
 
+  // The status endpoint sets a banner while a subsystem is degraded
+  const fetchStatusBanner = async (): Promise<SystemBanner | null> => {
+    try {
+      const response = await fetch('/api/v1/status');
+      if (!response.ok) return null;
+      const { data } = await response.json();
+      if (!data?.banner) return null;
+      return { ...data.banner, active: true, created_at: data.checked_at };
+    } catch (error) {
+      console.error('Failed to fetch status:', error);
+      return null;
+    }
+  };
+
   const dismissBanner = (bannerId: string) => {
     const updatedDismissed = [...dismissedBanners, bannerId];
     setDismissedBanners(updatedDismissed);